@@ -16,6 +16,27 @@ function getImpersonationToken(): string | null {
   return localStorage.getItem(IMPERSONATION_TOKEN_KEY)
 }
 
+// CSRF token of the session cookie, sent on state-changing requests
+const CSRF_HEADER = 'X-CSRF-Token'
+const SAFE_METHODS = ['GET', 'HEAD', 'OPTIONS']
+let csrfToken: Promise<string | null> | null = null
+
+// Get the CSRF token of the session, fetched once and kept until rejected
+function getCsrfToken(): Promise<string | null> {
+  if (!csrfToken) {
+    csrfToken = fetch(`${API_BASE}/csrf-token`, { credentials: 'include' })
+      .then(response => (response.ok ? response.json() : null))
+      .then(body => body?.data?.token ?? null)
+      .catch(() => null)
+      .then(token => {
+        // Without a session there is no token, ask again on the next request
+        if (!token) csrfToken = null
+        return token
+      })
+  }
+  return csrfToken
+}
+
 export class ApiError extends Error {
   status: number
   code: string
@@ -103,6 +124,14 @@ export async function apiClient<T>(
     headers[IMPERSONATION_HEADER] = impersonationToken
   }
 
+  // Add CSRF token on state-changing requests authenticated by the session cookie
+  if (!SAFE_METHODS.includes((fetchOptions.method ?? 'GET').toUpperCase())) {
+    const token = await getCsrfToken()
+    if (token) {
+      headers[CSRF_HEADER] = token
+    }
+  }
+
   const requestOptions: RequestInit = {
     ...fetchOptions,
     headers,
@@ -125,6 +154,11 @@ export async function apiClient<T>(
           error: { code: 'UNKNOWN', message: 'An error occurred' },
         }))
 
+        // The session changed, fetch its new CSRF token on the next request
+        if (response.status === 403 && body.error?.code === 'CSRF_INVALID') {
+          csrfToken = null
+        }
+
         // Check if we should retry
         if (attempt < maxAttempts - 1 && isRetryable(null, response.status)) {
           lastError = new ApiError(response.status, body)
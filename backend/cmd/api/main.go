@@ -10,24 +10,98 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mimi6060/festivals/backend/internal/config"
+	"github.com/mimi6060/festivals/backend/internal/domain/activity"
+	"github.com/mimi6060/festivals/backend/internal/domain/alertrule"
+	"github.com/mimi6060/festivals/backend/internal/domain/attestation"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/auth"
+	"github.com/mimi6060/festivals/backend/internal/domain/banktransfer"
+	"github.com/mimi6060/festivals/backend/internal/domain/branding"
+	"github.com/mimi6060/festivals/backend/internal/domain/budget"
+	"github.com/mimi6060/festivals/backend/internal/domain/bundle"
+	"github.com/mimi6060/festivals/backend/internal/domain/category"
+	"github.com/mimi6060/festivals/backend/internal/domain/corspolicy"
+	"github.com/mimi6060/festivals/backend/internal/domain/dayclose"
+	"github.com/mimi6060/festivals/backend/internal/domain/delivery"
+	"github.com/mimi6060/festivals/backend/internal/domain/display"
+	"github.com/mimi6060/festivals/backend/internal/domain/demo"
+	"github.com/mimi6060/festivals/backend/internal/domain/diagnostics"
+	"github.com/mimi6060/festivals/backend/internal/domain/donation"
+	"github.com/mimi6060/festivals/backend/internal/domain/duplicatecharge"
+	"github.com/mimi6060/festivals/backend/internal/domain/eta"
+	"github.com/mimi6060/festivals/backend/internal/domain/export"
+	"github.com/mimi6060/festivals/backend/internal/domain/failover"
+	"github.com/mimi6060/festivals/backend/internal/domain/feedback"
 	"github.com/mimi6060/festivals/backend/internal/domain/festival"
+	"github.com/mimi6060/festivals/backend/internal/domain/geoaccess"
+	"github.com/mimi6060/festivals/backend/internal/domain/honeypot"
+	"github.com/mimi6060/festivals/backend/internal/domain/keyrotation"
+	"github.com/mimi6060/festivals/backend/internal/domain/legacyimport"
+	"github.com/mimi6060/festivals/backend/internal/domain/locker"
+	"github.com/mimi6060/festivals/backend/internal/domain/magiclink"
+	"github.com/mimi6060/festivals/backend/internal/domain/media"
+	"github.com/mimi6060/festivals/backend/internal/domain/numbering"
+	"github.com/mimi6060/festivals/backend/internal/domain/oauth"
+	"github.com/mimi6060/festivals/backend/internal/domain/occupancy"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/orderfield"
 	"github.com/mimi6060/festivals/backend/internal/domain/payment"
+	"github.com/mimi6060/festivals/backend/internal/domain/posdevice"
+	"github.com/mimi6060/festivals/backend/internal/domain/presale"
+	"github.com/mimi6060/festivals/backend/internal/domain/printing"
 	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/publicstats"
 	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/domain/recall"
+	"github.com/mimi6060/festivals/backend/internal/domain/recommendation"
+	"github.com/mimi6060/festivals/backend/internal/domain/reconciliation"
+	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/mimi6060/festivals/backend/internal/domain/residency"
+	"github.com/mimi6060/festivals/backend/internal/domain/restock"
+	"github.com/mimi6060/festivals/backend/internal/domain/retention"
+	"github.com/mimi6060/festivals/backend/internal/domain/runbook"
+	"github.com/mimi6060/festivals/backend/internal/domain/search"
+	"github.com/mimi6060/festivals/backend/internal/domain/sensor"
+	"github.com/mimi6060/festivals/backend/internal/domain/sso"
 	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/domain/statuspage"
+	"github.com/mimi6060/festivals/backend/internal/domain/support"
+	"github.com/mimi6060/festivals/backend/internal/domain/suppression"
+	"github.com/mimi6060/festivals/backend/internal/domain/survey"
+	"github.com/mimi6060/festivals/backend/internal/domain/sync"
+	"github.com/mimi6060/festivals/backend/internal/domain/testclock"
+	"github.com/mimi6060/festivals/backend/internal/domain/transport"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/domain/vendorportal"
 	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/domain/walletbatch"
+	"github.com/mimi6060/festivals/backend/internal/domain/walletpass"
+	"github.com/mimi6060/festivals/backend/internal/domain/weather"
+	"github.com/mimi6060/festivals/backend/internal/domain/webhook"
+	"github.com/mimi6060/festivals/backend/internal/domain/webhooks"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/backpressure"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/database"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
 	stripepay "github.com/mimi6060/festivals/backend/internal/infrastructure/payment"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/qrcode"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/sms"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/storage"
+	weatherprovider "github.com/mimi6060/festivals/backend/internal/infrastructure/weather"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/websocket"
+	"github.com/mimi6060/festivals/backend/internal/jobs"
 	"github.com/mimi6060/festivals/backend/internal/middleware"
+	"github.com/mimi6060/festivals/backend/internal/pkg/geoip"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 
 	_ "github.com/mimi6060/festivals/backend/docs"
 )
@@ -72,32 +146,114 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
 
+	// Client IPs are truncated in the logs unless the environment keeps them
+	privacy.SetLogIPAnonymization(cfg.LogAnonymizeIP)
+
 	// Initialize Prometheus metrics
 	metrics := monitoring.Init("festivals")
 	log.Info().Msg("Prometheus metrics initialized")
 
+	// Endpoint SLOs, computed from the HTTP metrics and alerting when a budget burns too fast
+	sloCtx, stopSLOs := context.WithCancel(context.Background())
+	if sloConfig, err := monitoring.LoadSLOConfig(cfg.SLOConfigPath); err != nil {
+		log.Warn().Err(err).Msg("Endpoint SLOs will not be tracked")
+	} else {
+		sloTracker := monitoring.NewSLOTracker(metrics.Registry)
+		sloTracker.Configure(sloConfig)
+		alertManager := monitoring.NewBusinessAlertManager(cfg.SLOAlertWebhookURL, metrics.Registry)
+		sloTracker.RegisterAlertRules(alertManager)
+		metrics.SetSLOTracker(sloTracker)
+		go sloTracker.Start(sloCtx)
+		go alertManager.Start(sloCtx)
+		log.Info().Int("slos", len(sloConfig.SLOs)).Msg("Endpoint SLO tracking initialized")
+	}
+
 	// Connect to database
 	db, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 
-	// Connect to Redis
-	rdb, err := cache.Connect(cfg.RedisURL)
+	// Slow queries, counted per domain and sampled with EXPLAIN ANALYZE for the admins
+	var slowQueries diagnostics.SlowQuerySource
+	if cfg.SlowQueryThreshold > 0 {
+		slowQueryConfig := database.DefaultSlowQueryConfig()
+		slowQueryConfig.Threshold = cfg.SlowQueryThreshold
+		slowQueryConfig.ExplainRate = cfg.SlowQueryExplainRate
+		slowQueryPlugin := database.NewSlowQueryPlugin(slowQueryConfig)
+		if err := db.Use(slowQueryPlugin); err != nil {
+			log.Fatal().Err(err).Msg("Failed to install slow query detection")
+		}
+		slowQueries = slowQueryPlugin
+	}
+
+	// Recent statement durations, whose p99 drives the load shedding
+	dbLatency := database.NewLatencyPlugin(time.Minute)
+	if err := db.Use(dbLatency); err != nil {
+		log.Fatal().Err(err).Msg("Failed to install database latency tracking")
+	}
+
+	// Connect to Redis, one client and pool per subsystem
+	redisClients, err := cache.ConnectClients(cfg.RedisURL, map[string]cache.ClientOptions{
+		cache.SubsystemCache:     redisClientOptions(cfg.RedisCache, cfg.RedisNamespace),
+		cache.SubsystemRateLimit: redisClientOptions(cfg.RedisRateLimit, cfg.RedisNamespace),
+		cache.SubsystemSessions:  redisClientOptions(cfg.RedisSessions, cfg.RedisNamespace),
+		cache.SubsystemRealtime:  redisClientOptions(cfg.RedisRealtime, cfg.RedisNamespace),
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to Redis")
 	}
+	rdb := redisClients.Cache
+
+	// Memory budgets of the security audit and analytics keys, swept by one instance
+	memoryGovernor := cache.NewMemoryGovernor(rdb, cfg.RedisGovernanceInterval, cache.DefaultKeyBudgets(
+		int64(cfg.RedisAuditMemoryBudgetMB)<<20,
+		int64(cfg.RedisAnalyticsMemoryBudgetMB)<<20,
+	)...)
+	governanceCtx, stopGovernance := context.WithCancel(context.Background())
+	go memoryGovernor.Start(governanceCtx)
+
+	// Initialize asynq client for scheduling background tasks
+	queueClient, err := queue.NewClient(cfg.RedisURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create asynq client")
+	}
+
+	// Load shedding: the non-critical routes get 503 while the queue backlog or the
+	// database p99 is over its limit, so payments and orders keep the capacity left
+	var queueBacklog backpressure.QueueBacklog
+	if inspector, err := queue.NewInspector(cfg.RedisURL); err != nil {
+		log.Warn().Err(err).Msg("Queue backlog not watched by the load shedding")
+	} else {
+		queueBacklog = inspector
+	}
+	backpressureConfig := backpressure.DefaultConfig()
+	backpressureConfig.Interval = cfg.BackpressureInterval
+	backpressureConfig.MaxQueueBacklog = cfg.BackpressureMaxQueueBacklog
+	backpressureConfig.MaxDBP99 = cfg.BackpressureMaxDBP99
+	backpressureConfig.RetryAfter = cfg.BackpressureRetryAfter
+	loadShedder := backpressure.NewController(backpressureConfig, queueBacklog, dbLatency)
+	go loadShedder.Start(governanceCtx)
 
 	// Initialize health checker with all components
 	healthChecker := monitoring.NewHealthChecker(appVersion)
 	healthChecker.Register(monitoring.NewDatabaseChecker(db))
 	healthChecker.Register(monitoring.NewRedisChecker(rdb))
+
+	// Replication to the warm standby region, checked once regions are configured
+	replicationChecker := monitoring.NewReplicationChecker(db).WithMaxLag(cfg.MaxReplicationLag)
+	if cfg.Region != "" {
+		healthChecker.Register(replicationChecker)
+	}
 	healthHandler := monitoring.NewHealthHandler(healthChecker)
 
+	// Public status feed, from the health checks and the incidents posted by admins
+	statusHandler := statuspage.NewHandler(statuspage.NewService(statuspage.NewRepository(db), healthChecker))
+
 	// Initialize WebSocket hub and realtime service
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
-	realtimeService := realtime.NewService(wsHub, rdb)
+	realtimeService := realtime.NewService(wsHub, redisClients.Realtime)
 
 	// Setup Gin
 	if cfg.Environment == "production" {
@@ -109,10 +265,62 @@ func main() {
 	// Middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.Logger())
-	router.Use(middleware.CORSForEnvironment(cfg.Environment, cfg.CORSAllowedOrigins))
+	// CORS: the platform origins on every route, and the origins organizers allow for
+	// their festival web apps on the routes of their festival
+	corsPolicyConfig := corspolicy.DefaultConfig()
+	corsPolicyConfig.Production = cfg.Environment == "production"
+	corsPolicyService := corspolicy.NewService(corspolicy.NewRepository(db), corsPolicyConfig)
+	corsConfig := middleware.CORSConfigForEnvironment(cfg.Environment, cfg.CORSAllowedOrigins)
+	corsConfig.MaxAge = time.Duration(cfg.CORSMaxAge) * time.Second
+	corsConfig.FestivalOrigins = corsPolicyService
+	router.Use(middleware.CORSWithConfig(corsConfig))
 	router.Use(middleware.RequestID())
+	router.Use(middleware.Locale())
 	router.Use(middleware.MetricsWithConfig(middleware.DefaultMetricsConfig()))
 
+	// Decoy endpoints, blocking the IPs of the scanners requesting them on every route
+	var honeypotService *honeypot.Service
+	if cfg.HoneypotEnabled {
+		honeypotConfig := honeypot.DefaultConfig()
+		honeypotConfig.BlockTTL = cfg.HoneypotBlockTTL
+		honeypotConfig.TrustedNetworks = cfg.HoneypotTrustedNetworks
+		honeypotService, err = honeypot.NewService(honeypot.NewRepository(rdb), honeypotConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to configure honeypot")
+		}
+		router.Use(middleware.BlockIPs(honeypotService))
+		honeypot.NewHandler(honeypotService).RegisterDecoyRoutes(router)
+	}
+
+	// Geo-IP and ASN access rules, e.g. admin routes only reachable from the EU
+	var geoAccessService *geoaccess.Service
+	if cfg.GeoIPCountryDBPath != "" || cfg.GeoIPASNDBPath != "" {
+		geoAccessService, err = newGeoAccessService(cfg, db)
+		if err != nil {
+			log.Warn().Err(err).Msg("Geo access rules disabled")
+		} else {
+			router.Use(middleware.GeoAccess(geoAccessService))
+		}
+	}
+
+	// Warm standby failover: the requests changing data are refused with structured
+	// errors while the region is a standby, read-only or being promoted
+	failoverConfig := failover.DefaultConfig()
+	failoverConfig.Region = cfg.Region
+	failoverConfig.MaxLag = cfg.MaxReplicationLag
+	failoverService := failover.NewService(failover.NewRepository(db), replicationChecker, failoverConfig)
+	failoverService.SetBroadcaster(redisClients.Realtime)
+	failoverService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	failoverCtx, stopFailover := context.WithCancel(context.Background())
+	if err := failoverService.Load(failoverCtx); err != nil {
+		log.Warn().Err(err).Msg("Failover state not loaded, writes accepted")
+	}
+	if err := failoverService.Listen(failoverCtx); err != nil {
+		log.Warn().Err(err).Msg("Failover state changes from other instances will not be applied")
+	}
+	failoverHandler := failover.NewHandler(failoverService)
+	router.Use(failoverHandler.ReadOnly("/api/v1/admin/failover"))
+
 	// Health check endpoints
 	healthHandler.RegisterRoutes(router)
 
@@ -158,6 +366,19 @@ func main() {
 	walletRepo := wallet.NewRepository(db)
 	standRepo := stand.NewRepository(db)
 	productRepo := product.NewRepository(db)
+	priceUpdateRepo := product.NewPriceUpdateRepository(db)
+	priceListRepo := product.NewPriceListRepository(db)
+	categoryRepo := category.NewRepository(db)
+	searchRepo := search.NewRepository(db)
+	orderRepo := order.NewRepository(db)
+	weatherRepo := weather.NewRepository(db)
+	feedbackRepo := feedback.NewRepository(db)
+	surveyRepo := survey.NewRepository(db)
+	suppressionRepo := suppression.NewRepository(db)
+	mediaRepo := media.NewRepository(db)
+	brandingRepo := branding.NewRepository(db)
+	numberingRepo := numbering.NewRepository(db)
+	exportRepo := export.NewRepository(db)
 
 	// Initialize Stripe client
 	var stripeClient *stripepay.StripeClient
@@ -171,11 +392,380 @@ func main() {
 		log.Warn().Msg("Stripe not configured - payment features disabled")
 	}
 
+	// Initialize object storage for uploaded media (branding assets)
+	var mediaService *media.Service
+	if cfg.MinioEndpoint != "" {
+		minioStorage, err := storage.NewMinioStorage(storage.MinioConfig{
+			Endpoint:        cfg.MinioEndpoint,
+			AccessKeyID:     cfg.MinioAccessKey,
+			SecretAccessKey: cfg.MinioSecretKey,
+			DefaultBucket:   cfg.MinioBucket,
+			UseSSL:          cfg.Environment == "production",
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to initialize MinIO storage - media uploads disabled")
+		} else {
+			mediaService = media.NewService(mediaRepo, minioStorage, cfg.MinioBucket)
+			log.Info().Msg("Connected to MinIO storage")
+		}
+	}
+
+	// Initialize weather provider (used for on-demand backfills; the worker ingests hourly)
+	weatherProvider, err := weatherprovider.NewProvider(weatherprovider.Config{
+		Provider: cfg.WeatherProvider,
+		BaseURL:  cfg.WeatherAPIURL,
+		APIKey:   cfg.WeatherAPIKey,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize weather provider")
+	}
+
+	// Signing secrets, rotated at runtime on SIGHUP or through the admin endpoint
+	keyring := security.NewKeyring(cfg.JWTSecret, cfg.JWTPreviousSecrets, cfg.JWTKeyOverlap)
+
 	// Initialize services
 	festivalService := festival.NewService(festivalRepo, db)
+	festivalService.SetDayRecomputer(jobs.NewAnalyticsQueue(queueClient))
 	walletService := wallet.NewService(walletRepo, cfg.JWTSecret)
+	walletService.SetKeyring(keyring)
 	standService := stand.NewService(standRepo)
 	productService := product.NewService(productRepo)
+	categoryService := category.NewService(categoryRepo)
+	standService.SetCategoryResolver(categoryService)
+	productService.SetCategoryResolver(categoryService)
+	standService.SetLocaleResolver(festivalService)
+	productService.SetLocaleResolver(standService)
+	categoryService.SetLocaleResolver(festivalService)
+	priceUpdateService := product.NewPriceUpdateService(priceUpdateRepo, productRepo, queueClient)
+	priceListService := product.NewPriceListService(priceListRepo, productRepo)
+	// Sandbox festivals on a test clock run their scheduled jobs at its virtual time;
+	// advancing it runs the jobs again
+	testClockService := testclock.NewService(testclock.NewRepository(db))
+	testClockService.SetScheduler(queueClient, product.TypeActivatePriceLists, order.TypeCancelStaleOrders, wallet.TypeThawWallets, presale.TypeActivatePreSales, wallet.TypeSendActivityDigests)
+	priceListService.SetClock(testClockService)
+	searchService := search.NewService(searchRepo, rdb)
+	weatherService := weather.NewService(weatherRepo, weatherProvider)
+	feedbackService := feedback.NewService(feedbackRepo)
+	standService.SetRatingProvider(feedbackService)
+	surveyService := survey.NewService(surveyRepo)
+	suppressionService := suppression.NewService(suppressionRepo, rdb)
+	brandingService := branding.NewService(brandingRepo, rdb)
+	if mediaService != nil {
+		brandingService.SetAssetUploader(mediaService)
+	}
+	// Attendee wallet statements are rendered in-process and emailed through the worker
+	emailQueue := jobs.NewEmailQueue(queueClient)
+	walletService.SetStatementRenderer(reports.NewService(nil, nil, nil, ""))
+	walletService.SetStatementBranding(brandingService)
+	walletService.SetStatementMailer(emailQueue)
+	walletService.SetFreezeNotifier(emailQueue)
+	walletService.SetActivityNotifier(jobs.NewPushQueue(queueClient))
+	walletService.SetCredentialBroadcaster(realtimeService)
+	walletService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	numberingService := numbering.NewService(numberingRepo)
+	exportService := export.NewService(exportRepo)
+	printingService := printing.NewService(printing.NewRepository(db), rdb)
+
+	// OAuth2 machine clients of the public API, their tokens signed through the keyring
+	oauthService := oauth.NewService(oauth.NewRepository(db), keyring, rdb)
+
+	// Organizer activity feed, tailed live on the dashboard channel
+	activityService := activity.NewService(activity.NewRepository(db), rdb)
+	activityService.SetBroadcaster(realtimeService)
+	standService.SetActivityRecorder(activityService)
+	productService.SetActivityRecorder(activityService)
+
+	// Apple Wallet and Google Wallet passes for tickets and balance cards
+	walletPassEncryptor, err := walletpass.NewKeyEncryptor(cfg.WalletPassEncryptionKey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize wallet pass key encryption")
+	}
+	walletPassService := walletpass.NewService(
+		walletpass.NewRepository(db),
+		walletPassEncryptor,
+		qrcode.NewGenerator(qrcode.Config{SecretKey: cfg.QRCodeSecret, QRSize: cfg.QRCodeSize}),
+		walletpass.Config{
+			WebServiceURL:    cfg.WalletPassWebServiceURL,
+			OrganizationName: cfg.WalletPassOrganization,
+			Origins:          cfg.WalletPassOrigins,
+		},
+	)
+
+	// Key rotation, applied on every API and worker instance through Redis
+	rotationCtx, stopRotation := context.WithCancel(context.Background())
+	keyRotationService := keyrotation.NewService(keyring, cfg.LoadJWTSecrets)
+	keyRotationService.SetJWKSReloader(middleware.ReloadJWKS)
+	keyRotationService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	keyRotationService.SetBroadcaster(redisClients.Realtime)
+	if err := keyRotationService.Listen(rotationCtx); err != nil {
+		log.Warn().Err(err).Msg("Key rotations from other instances will not be applied")
+	}
+	go keyRotationService.ReloadOnSignal(rotationCtx)
+
+	// Security auditor, alerting through the channels selected by the admin-defined rules
+	securityAuditor := security.NewSecurityAuditor(security.DefaultAuditConfig(), rdb)
+	securityAuditor.SetStorageBudget(memoryGovernor)
+	if cfg.SlackWebhookURL != "" {
+		securityAuditor.AddAlertChannel("slack", security.NewSlackAlertHandler(cfg.SlackWebhookURL, "", ""))
+	}
+	if len(cfg.AlertWebhookURLs) > 0 {
+		securityAuditor.AddAlertChannel("webhook", security.NewWebhookAlertHandler(cfg.AlertWebhookURLs, cfg.AlertWebhookSecret, nil))
+	}
+	if honeypotService != nil {
+		honeypotService.SetAuditor(securityAuditor)
+	}
+	if incidentConfig, err := security.LoadIncidentConfig(cfg.IncidentConfigPath); err != nil {
+		log.Warn().Err(err).Msg("Security alerts will not open PagerDuty or Opsgenie incidents")
+	} else if incidentConfig.Enabled() {
+		securityAuditor.AddAlertChannel("incidents", security.NewIncidentAlertHandler(*incidentConfig))
+	}
+	alertRulesCtx, stopAlertRules := context.WithCancel(context.Background())
+	alertRuleService := alertrule.NewService(alertrule.NewRepository(db), securityAuditor)
+	alertRuleService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	alertRuleService.SetBroadcaster(redisClients.Realtime)
+	if err := alertRuleService.Load(alertRulesCtx); err != nil {
+		log.Warn().Err(err).Msg("Security alert rules not loaded, using the static thresholds")
+	}
+	if err := alertRuleService.Listen(alertRulesCtx); err != nil {
+		log.Warn().Err(err).Msg("Security alert rule changes from other instances will not be applied")
+	}
+	if geoAccessService != nil {
+		geoAccessService.SetAuditor(securityAuditor)
+		geoAccessService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+		geoAccessService.SetBroadcaster(redisClients.Realtime)
+		if err := geoAccessService.Load(alertRulesCtx); err != nil {
+			log.Warn().Err(err).Msg("Geo access overrides not loaded")
+		}
+		if err := geoAccessService.Listen(alertRulesCtx); err != nil {
+			log.Warn().Err(err).Msg("Geo access override changes from other instances will not be applied")
+		}
+	}
+	corsPolicyService.SetAuditor(securityAuditor)
+	corsPolicyService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	corsPolicyService.SetBroadcaster(redisClients.Realtime)
+	if err := corsPolicyService.Listen(alertRulesCtx); err != nil {
+		log.Warn().Err(err).Msg("Festival CORS origin changes from other instances will only apply once cached origins expire")
+	}
+
+	// Stand wait-time estimates, refreshed in the background and alerting organizers
+	waitTimeService := order.NewWaitTimeService(orderRepo, rdb, order.DefaultWaitTimeConfig())
+	waitTimeService.SetAlerter(activityService)
+	waitTimeService.Start()
+	standService.SetWaitTimeProvider(waitTimeService)
+
+	// Festival budgets, checked in the background for lines trending to miss
+	budgetService := budget.NewService(budget.NewRepository(db))
+	budgetService.SetAlerter(activityService)
+	budgetService.Start()
+
+	// Vendor portal, scoped to the stands each vendor owns. Menu changes made by vendors
+	// are published once approved by an organizer.
+	vendorService := vendorportal.NewService(vendorportal.NewRepository(db), productService, standService)
+	vendorService.SetQueue(queueClient)
+	vendorService.SetNotifier(emailQueue)
+
+	// End-of-day closes; the orders of closed business days only change through
+	// audited adjustments
+	orderService := order.NewService(orderRepo, productRepo, walletService)
+	dayCloseService := dayclose.NewService(dayclose.NewRepository(db), numberingService, orderService)
+	dayCloseService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+
+	// Signed hash chain of the Z-reports and financial exports, checked by auditors
+	attestationService := attestation.NewService(attestation.NewRepository(db), keyring)
+	attestationService.SetContentSource(attestation.DocumentDayClose, dayCloseService)
+	dayCloseService.SetAttester(attestationService)
+	orderService.SetClosedDayChecker(dayCloseService)
+
+	// Table and camping pitch delivery, ordered to by scanning the location QR code
+	deliveryService := delivery.NewService(
+		delivery.NewRepository(db),
+		orderService,
+		qrcode.NewGenerator(qrcode.Config{SecretKey: cfg.QRCodeSecret, QRSize: cfg.QRCodeSize}),
+		[]byte(cfg.QRCodeSecret),
+	)
+	orderService.SetDeliveryLocationResolver(deliveryService)
+
+	// Custom fields organizers ask for on orders, e.g. a table number or an allergy note
+	orderFieldService := orderfield.NewService(orderfield.NewRepository(db))
+	orderService.SetCustomFieldValidator(orderFieldService)
+
+	// Stock of limited products is held from order creation until payment
+	orderService.SetStockReserver(product.NewStockReservationRepository(db), order.DefaultStockHoldTTL)
+
+	// Menu recommendations, computed by the analytics worker
+	recommendationService := recommendation.NewService(recommendation.NewRepository(db), rdb, recommendation.DefaultConfig())
+
+	// Order preparation time predictions, from models trained by the analytics worker
+	etaService := eta.NewService(eta.NewRepository(db), rdb, eta.DefaultConfig())
+
+	// Product recalls across the stands; purchasers are refunded and notified by the worker
+	recallService := recall.NewService(recall.NewRepository(db), queueClient)
+
+	// Lockers and gear checks rented to attendees and paid from their wallet
+	lockerService := locker.NewService(locker.NewRepository(db), walletService)
+	lockerService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+
+	// Search of orders and transactions for support staff, every search audited
+	supportService := support.NewService(support.NewRepository(db))
+	supportService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+
+	// Parking and shuttle passes paid from the wallet, with QR codes in the ticket format
+	transportService := transport.NewService(
+		transport.NewRepository(db),
+		walletService,
+		qrcode.NewGenerator(qrcode.Config{SecretKey: cfg.QRCodeSecret, QRSize: cfg.QRCodeSize}),
+	)
+	recallService.SetMenuRefresher(recommendationService)
+	recallService.SetBroadcaster(realtimeService)
+	recallService.SetJobBroadcaster(realtimeService)
+
+	// Bulk wallet credits and debits; the wallets are adjusted by the worker
+	walletBatchService := walletbatch.NewService(walletbatch.NewRepository(db), queueClient)
+	publicStatsService := publicstats.NewService(publicstats.NewRepository(db), rdb)
+	walletBatchService.SetJobBroadcaster(realtimeService)
+	walletBatchService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	walletBatchService.SetApprovalThreshold(cfg.WalletBatchApprovalThreshold)
+
+	// Wallet top-ups by bank transfer, matched from bank statements and the virtual IBAN
+	// provider webhook
+	bankTransferConfig := banktransfer.DefaultConfig()
+	bankTransferConfig.AccountHolder = cfg.BankTransferAccountHolder
+	bankTransferConfig.IBAN = cfg.BankTransferIBAN
+	bankTransferConfig.BIC = cfg.BankTransferBIC
+	bankTransferConfig.OrderValidity = cfg.BankTransferValidity
+	bankTransferService := banktransfer.NewService(banktransfer.NewRepository(db), walletService, bankTransferConfig)
+	if cfg.VirtualIBANAPIURL != "" {
+		bankTransferService.SetIBANProvider(stripepay.NewVirtualIBANClient(stripepay.VirtualIBANConfig{
+			BaseURL: cfg.VirtualIBANAPIURL,
+			APIKey:  cfg.VirtualIBANAPIKey,
+		}))
+	}
+
+	// Fridge and keg sensors of the bars, alerting the dashboards and opening restock tasks
+	sensorService := sensor.NewService(sensor.NewRepository(db))
+	sensorService.SetAlerter(activityService)
+
+	// Restock requests of the stands to the central warehouse, including kegs nearly empty
+	restockService := restock.NewService(restock.NewRepository(db))
+	restockService.SetAlerter(activityService)
+	sensorService.SetRestockRequester(restockService)
+
+	// Review of duplicate wallet charges, detected by the worker
+	duplicateChargeService := duplicatecharge.NewService(duplicatecharge.NewRepository(db), walletService, duplicatecharge.DefaultConfig())
+	duplicateChargeService.SetNotifier(emailQueue)
+
+	// POS terminals paired to the stands by scanning a QR code from the dashboard
+	posDeviceRepo := posdevice.NewRepository(db)
+	posDeviceService := posdevice.NewService(
+		posDeviceRepo,
+		qrcode.NewGenerator(qrcode.Config{SecretKey: cfg.QRCodeSecret, QRSize: cfg.QRCodeSize}),
+	)
+	posDeviceService.SetSigningSecrets(keyring)
+
+	// Change sequences of the wallets, prices and products, which the POS terminals
+	// compare to their cache before accepting a transaction offline
+	posSequencer := posdevice.NewSequencer(posdevice.NewSequenceStore(rdb), posDeviceRepo)
+	posDeviceService.SetSequencer(posSequencer)
+	walletService.SetChangeSequencer(posSequencer)
+	productService.SetChangeSequencer(posSequencer)
+	priceListService.SetChangeSequencer(posSequencer)
+	priceUpdateService.SetChangeSequencer(posSequencer)
+
+	// User role changes, audited and notified to the user and the administrators
+	userService := user.NewService(user.NewRepository(db))
+	userService.SetNotifier(emailQueue)
+	userService.SetAuditor(securityAuditor)
+
+	// OpenID Connect SSO of the organizers, whose session tokens are accepted next to
+	// Auth0 tokens and follow the keyring rotations
+	ssoEncryptor, err := sso.NewSecretEncryptor(cfg.SSOEncryptionKey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize SSO client secret encryption")
+	}
+	ssoService := sso.NewService(sso.NewRepository(db), userService, ssoEncryptor, keyring, sso.Config{
+		CallbackURL:    cfg.SSOCallbackURL,
+		AllowedOrigins: cfg.SSORedirectOrigins,
+	})
+	ssoService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	middleware.SetSessionKeys(keyring)
+
+	// Magic link sign-in of the attendees, whose session tokens are accepted like those
+	// of SSO sign-ins
+	magicLinkService := magiclink.NewService(magiclink.NewRepository(rdb), userService, emailQueue, keyring, magiclink.Config{
+		LinkURL: cfg.MagicLinkURL,
+	})
+	magicLinkService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+
+	// Wallet reconciliation reports, run nightly by the worker or on demand
+	reconciliationConfig := reconciliation.DefaultConfig()
+	reconciliationConfig.Threshold = cfg.ReconciliationThreshold
+	reconciliationService := reconciliation.NewService(reconciliation.NewRepository(db), reconciliationConfig)
+	reconciliationService.SetAlerter(monitoring.NewWebhookAlerter(cfg.ReconciliationAlertWebhookURL))
+	reconciliationService.SetJobBroadcaster(realtimeService)
+	if stripeClient != nil {
+		reconciliationService.SetStripeVerifier(stripeClient)
+	}
+
+	// Data residency policies, checked against the providers configured for the deployment
+	residencyService := residency.NewService(residency.NewRepository(db), residency.NewCatalog(residency.CatalogConfig{
+		StorageEndpoint:   cfg.MinioEndpoint,
+		StorageBucket:     cfg.MinioBucket,
+		StorageRegion:     cfg.MinioRegion,
+		EUStorageEndpoint: cfg.MinioEUEndpoint,
+		EUStorageBucket:   cfg.MinioEUBucket,
+		EUStorageRegion:   cfg.MinioEURegion,
+		PostalURL:         cfg.PostalURL,
+		PostalRegion:      cfg.PostalRegion,
+		PostalEUURL:       cfg.PostalEUURL,
+		PostalEURegion:    cfg.PostalEURegion,
+		TwilioEnabled:     cfg.TwilioAccountSID != "" && cfg.TwilioAuthToken != "",
+		TwilioHost:        sms.TwilioHost(cfg.TwilioRegion, cfg.TwilioEdge),
+		TwilioRegion:      cfg.TwilioRegion,
+	}))
+
+	// Entry bundles: a ticket and a wallet credit paid in one card payment, sold once
+	// Stripe is configured
+	bundleService := bundle.NewService(bundle.NewRepository(db), walletService)
+	bundleService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+
+	// Wallet pre-sales: top-ups paid before the gates open, credited with their campaign
+	// bonus when the gates open
+	preSaleService := presale.NewService(presale.NewRepository(db), walletService)
+	preSaleService.SetClock(testClockService)
+
+	// Round-up donations: purchases of the opted-in wallets rounded up to the next euro
+	// for the charity of their festival
+	donationService := donation.NewService(donation.NewRepository(db))
+	walletService.SetRoundUpCollector(donationService)
+
+	// Menu boards: read-only stand tokens for the menu, wait time and now-serving numbers
+	displayService := display.NewService(display.NewRepository(db), waitTimeService)
+	displayService.SetPriceLists(priceListService)
+	displayService.SetDisconnector(realtimeService)
+
+	// Crowded areas overlay of the festival map for the attendee apps
+	occupancyService := occupancy.NewService(occupancy.NewRepository(db), rdb)
+	occupancyService.SetWaitTimes(waitTimeService)
+
+	// Legacy imports: wallets, transactions and products exported by a previous cashless provider
+	legacyImportService := legacyimport.NewService(legacyimport.NewRepository(db))
+
+	// Runbook: audited fixes of a live event, dry runs by default
+	syncService := sync.NewService(sync.NewRepository(db), walletRepo, cfg.JWTSecret)
+	syncService.SetKeyring(keyring)
+	syncService.SetChangeSequencer(posSequencer)
+	runbookService := runbook.NewService(runbook.NewRepository(db), rdb, runbook.Aggregates{
+		WaitTimes:       waitTimeService,
+		Recommendations: recommendationService,
+		ETAModels:       etaService,
+		PublicStats:     publicStatsService,
+	})
+	runbookService.SetWebhooks(webhooks.NewService(webhooks.NewRepository(db)))
+	runbookService.SetDevices(syncService)
+	runbookService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+
+	// Webhook deliveries are queued per priority class and sent by the worker
+	webhookService := webhook.NewService(webhook.NewRepository(db), webhook.NewSender(webhook.DefaultSenderConfig()), queueClient, webhook.DefaultServiceConfig())
 
 	// Initialize payment service (if Stripe is configured)
 	var paymentHandler *payment.Handler
@@ -186,6 +776,10 @@ func main() {
 		}
 		paymentService := payment.NewService(db, stripeClient, baseURL)
 		paymentService.SetWalletService(walletService)
+		paymentService.SetBundleFulfiller(bundleService)
+		bundleService.SetPaymentGateway(paymentService)
+		paymentService.SetPreSaleFulfiller(preSaleService)
+		preSaleService.SetPaymentGateway(paymentService)
 		paymentHandler = payment.NewHandler(paymentService, stripeClient)
 		log.Info().Msg("Payment service initialized")
 	}
@@ -195,6 +789,65 @@ func main() {
 	walletHandler := wallet.NewHandler(walletService)
 	standHandler := stand.NewHandler(standService)
 	productHandler := product.NewHandler(productService)
+	priceUpdateHandler := product.NewPriceUpdateHandler(priceUpdateService)
+	priceListHandler := product.NewPriceListHandler(priceListService)
+	categoryHandler := category.NewHandler(categoryService)
+	searchHandler := search.NewHandler(searchService)
+	waitTimeHandler := order.NewWaitTimeHandler(waitTimeService)
+	autoCancelHandler := order.NewAutoCancelHandler(order.NewAutoCancelService(orderRepo))
+	weatherHandler := weather.NewHandler(weatherService)
+	feedbackHandler := feedback.NewHandler(feedbackService)
+	activityHandler := activity.NewHandler(activityService)
+	surveyHandler := survey.NewHandler(surveyService)
+	suppressionHandler := suppression.NewHandler(suppressionService)
+	brandingHandler := branding.NewHandler(brandingService)
+	numberingHandler := numbering.NewHandler(numberingService)
+	printingHandler := printing.NewHandler(printingService)
+	oauthHandler := oauth.NewHandler(oauthService)
+	ssoHandler := sso.NewHandler(ssoService)
+	magicLinkHandler := magiclink.NewHandler(magicLinkService)
+	budgetHandler := budget.NewHandler(budgetService)
+	vendorHandler := vendorportal.NewHandler(vendorService)
+	dayCloseHandler := dayclose.NewHandler(dayCloseService)
+	attestationHandler := attestation.NewHandler(attestationService)
+	deliveryHandler := delivery.NewHandler(deliveryService)
+	orderFieldHandler := orderfield.NewHandler(orderFieldService)
+	recommendationHandler := recommendation.NewHandler(recommendationService)
+	etaHandler := eta.NewHandler(etaService)
+	recallHandler := recall.NewHandler(recallService)
+	lockerHandler := locker.NewHandler(lockerService)
+	transportHandler := transport.NewHandler(transportService)
+	bundleHandler := bundle.NewHandler(bundleService)
+	preSaleHandler := presale.NewHandler(preSaleService)
+	donationHandler := donation.NewHandler(donationService)
+	sensorHandler := sensor.NewHandler(sensorService)
+	restockHandler := restock.NewHandler(restockService)
+	posDeviceHandler := posdevice.NewHandler(posDeviceService)
+	displayHandler := display.NewHandler(displayService)
+	occupancyHandler := occupancy.NewHandler(occupancyService)
+	webhookHandler := webhook.NewHandler(webhookService)
+	corsPolicyHandler := corspolicy.NewHandler(corsPolicyService)
+	duplicateChargeHandler := duplicatecharge.NewHandler(duplicateChargeService)
+	reconciliationHandler := reconciliation.NewHandler(reconciliationService)
+	residencyHandler := residency.NewHandler(residencyService)
+	walletBatchHandler := walletbatch.NewHandler(walletBatchService)
+	legacyImportHandler := legacyimport.NewHandler(legacyImportService)
+	retentionHandler := retention.NewHandler(retention.NewPolicyService(retention.NewPolicyRepository(db)))
+	bankTransferHandler := banktransfer.NewHandler(bankTransferService)
+	bankTransferWebhookHandler := banktransfer.NewWebhookHandler(bankTransferService, cfg.VirtualIBANWebhookSecret)
+	publicStatsHandler := publicstats.NewHandler(publicStatsService)
+	demoHandler := demo.NewHandler(demo.NewService(demo.NewRepository(db), festivalService))
+	testClockHandler := testclock.NewHandler(testClockService)
+	supportHandler := support.NewHandler(supportService)
+	exportHandler := export.NewHandler(exportService)
+	keyRotationHandler := keyrotation.NewHandler(keyRotationService)
+	alertRuleHandler := alertrule.NewHandler(alertRuleService)
+	userHandler := user.NewHandler(userService)
+	walletPassHandler := walletpass.NewHandler(walletPassService)
+	suppressionWebhookHandler := suppression.NewWebhookHandler(suppressionService, suppression.WebhookConfig{
+		EmailSecret:     cfg.EmailWebhookSecret,
+		TwilioAuthToken: cfg.TwilioAuthToken,
+	})
 
 	// Webhook routes (no auth required, signature verification done in handler)
 	webhooks := router.Group("/webhooks")
@@ -202,19 +855,88 @@ func main() {
 		if paymentHandler != nil {
 			paymentHandler.RegisterWebhookRoutes(webhooks)
 		}
+
+		// Bounces, spam complaints and STOP replies from delivery providers
+		suppressionWebhookHandler.RegisterWebhookRoutes(webhooks.Group("/suppressions"))
+
+		// Transfers credited to the virtual IBANs of bank transfer top-up orders
+		bankTransferWebhookHandler.RegisterWebhookRoutes(webhooks.Group("/bank-transfers"))
 	}
 
+	// CSRF protection of the dashboard session cookies, Bearer clients are not checked
+	sessionConfig := middleware.DefaultSessionConfig()
+	sessionConfig.RedisClient = rdb
+	csrfConfig := middleware.DefaultSessionCSRFConfig(middleware.NewSessionManager(sessionConfig))
+	csrfConfig.Auditor = securityAuditor
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.SessionCSRF(csrfConfig))
 	{
+		// CSRF token of the session cookie, sent back by the dashboard in X-CSRF-Token
+		v1.GET("/csrf-token", middleware.CSRFTokenHandler(csrfConfig))
+
 		// Public routes
 		v1.GET("/festivals/:id/public", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "Festival public info"})
 		})
 
+		// White-label config consumed by the apps (by festival ID, slug or custom domain)
+		brandingHandler.RegisterPublicRoutes(v1)
+
+		// Anonymized stats embedded on festival websites, once enabled by the organizer
+		publicStatsHandler.RegisterPublicRoutes(v1)
+
+		// Platform status feed polled by the monitoring of organizers
+		statusHandler.RegisterPublicRoutes(v1, middleware.RateLimitByEndpoint(redisClients.RateLimit, 30))
+
+		// Apple Wallet web service, authenticated with the token of each pass
+		walletPassHandler.RegisterWebhookRoutes(v1.Group("/passes"))
+
+		// Stand print agents, authenticated with the token of their printer
+		printingHandler.RegisterAgentRoutes(v1.Group("/print-agent"))
+
+		// Stand sensor gateways, authenticated with the API key of their device
+		sensorHandler.RegisterDeviceRoutes(v1.Group("/sensor-gateway"))
+
+		// POS terminals pairing with a scanned code, then authenticated with their device
+		// token, their requests signed against replay
+		signedRequests := posdevice.DefaultSignedRequestConfig(keyring, rdb, posdevice.DeviceIDKey)
+		signedRequests.MaxSkew = cfg.RequestSigningMaxSkew
+		signedRequests.Required = cfg.RequestSigningRequired
+		signedRequests.Auditor = securityAuditor
+		verifySignature := posdevice.SignedRequests(signedRequests)
+		posDeviceHandler.RegisterDeviceRoutes(v1.Group("/pos-device"), verifySignature)
+
+		// Digital menu boards, authenticated with the display token of their stand
+		displayHandler.RegisterBoardRoutes(v1.Group("/display"), websocket.MenuBoardHandler(wsHub))
+
+		// OAuth2 client credentials token endpoint
+		oauthHandler.RegisterTokenRoutes(v1.Group("/oauth"))
+
+		// SSO sign-in of the organizers with the OpenID Connect provider of their organization
+		ssoHandler.RegisterLoginRoutes(v1.Group("/auth/sso"))
+
+		// Magic link sign-in of the attendees, limited per IP with a lockout like the other
+		// sign-ins and per email by the service
+		authRateLimit := middleware.DefaultAuthRateLimitConfig()
+		authRateLimit.RedisClient = redisClients.RateLimit
+		magicLinkHandler.RegisterRoutes(v1.Group("/auth/magic-link", middleware.AuthRateLimit(authRateLimit)))
+
+		// Third-party integrations, authenticated with client access tokens and
+		// checked against their scopes
+		integrations := v1.Group("/integrations/festivals/:id")
+		integrations.Use(oauthHandler.Authenticate())
+		{
+			integrations.GET("/exports/transactions", oauth.RequireScope(auth.PermTransactionsExport), exportHandler.ExportTransactions)
+			integrations.GET("/exports/orders", oauth.RequireScope(auth.PermOrdersExport), exportHandler.ExportOrders)
+			integrations.GET("/exports/analytics-events", oauth.RequireScope(auth.PermReportsExport), exportHandler.ExportAnalyticsEvents)
+		}
+
 		// Protected routes
 		protected := v1.Group("")
 		protected.Use(middleware.AuthWithSimpleConfig(cfg.Auth0Domain, cfg.Auth0Audience))
+		protected.Use(middleware.UserLocale(db))
 		{
 			// User routes
 			protected.GET("/me", func(c *gin.Context) {
@@ -224,14 +946,76 @@ func main() {
 			// Festival management routes (admin)
 			festivalHandler.RegisterRoutes(protected)
 
-			// Wallet routes (user)
-			walletHandler.RegisterRoutes(protected)
+			// Wallet routes (user); the payments, refunds and top-ups of POS terminals
+			// are signed like their device requests
+			walletHandler.RegisterRoutes(protected, posDeviceHandler.IdentifyDevice, verifySignature)
+
+			// Apple Wallet and Google Wallet passes (user)
+			walletPassHandler.RegisterRoutes(protected)
 
 			// Payment routes (Stripe)
 			if paymentHandler != nil {
 				paymentHandler.RegisterRoutes(protected)
 			}
 
+			// Platform administration routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.RequireRole(middleware.RoleAdmin))
+			{
+				// Email/SMS suppression list
+				suppressionHandler.RegisterRoutes(admin)
+
+				// Signing secret and Auth0 key rotation
+				keyRotationHandler.RegisterRoutes(admin)
+
+				// Security alert rules
+				alertRuleHandler.RegisterRoutes(admin)
+
+				// Region failover: read-only mode and promotion of the standby
+				failoverHandler.RegisterRoutes(admin)
+
+				// Incidents of the public status feed
+				statusHandler.RegisterRoutes(admin)
+
+				// Slow queries and their plans, Redis memory per key prefix
+				diagnostics.NewHandler(slowQueries, memoryGovernor).RegisterRoutes(admin)
+
+				// Runbook: rebuild aggregates, redeliver webhooks, resync devices, recompute wallets
+				runbook.NewHandler(runbookService).RegisterRoutes(admin)
+
+				// IPs blocked by the honeypot
+				if honeypotService != nil {
+					honeypot.NewHandler(honeypotService).RegisterRoutes(admin)
+				}
+
+				// Geo access rules and their runtime overrides
+				if geoAccessService != nil {
+					geoaccess.NewHandler(geoAccessService).RegisterRoutes(admin)
+				}
+
+				// User roles, elevation requires multi-factor authentication
+				userHandler.RegisterAdminRoutes(admin)
+
+				// Wallet pass signing certificates
+				walletPassHandler.RegisterAdminRoutes(admin)
+
+				// OpenID Connect connections of the organizations
+				ssoHandler.RegisterRoutes(admin)
+
+				// Priority classes of the webhooks, along with the paid plans
+				webhookHandler.RegisterAdminRoutes(admin)
+
+				// Demo festival seeding, never in production
+				if cfg.Environment != "production" {
+					demoHandler.RegisterRoutes(admin)
+				}
+			}
+
+			// Vendor portal: products, sales, statements, payouts and staff of owned stands
+			vendorPortal := protected.Group("/vendor")
+			vendorPortal.Use(middleware.RequireRole(middleware.RoleVendor))
+			vendorHandler.RegisterPortalRoutes(vendorPortal, middleware.RequireStandOwnership(vendorService))
+
 			// Festival-scoped routes (requires tenant middleware)
 			festivalScoped := protected.Group("/festivals/:id")
 			festivalScoped.Use(middleware.Tenant(db))
@@ -242,9 +1026,255 @@ func main() {
 
 				// Stand management
 				standHandler.RegisterRoutes(festivalScoped)
+				waitTimeHandler.RegisterRoutes(festivalScoped)
 
-				// Product management
+				// Product management; allergen compliance report, organizers only
 				productHandler.RegisterRoutes(festivalScoped)
+				allergenReport := festivalScoped.Group("")
+				allergenReport.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				productHandler.RegisterComplianceRoutes(allergenReport)
+				priceUpdateHandler.RegisterRoutes(festivalScoped)
+				priceListHandler.RegisterRoutes(festivalScoped)
+
+				// Category taxonomy
+				categoryHandler.RegisterRoutes(festivalScoped)
+
+				// Festival search (stands, products, lineup)
+				searchHandler.RegisterFestivalRoutes(festivalScoped)
+
+				// Non-critical routes, shed while the queues or the database fall behind
+				sheddable := festivalScoped.Group("", loadShedder.Shed())
+
+				// Weather observations for analytics
+				weatherHandler.RegisterRoutes(sheddable)
+
+				// Attendee feedback and stand ratings
+				feedbackHandler.RegisterRoutes(sheddable)
+
+				// Post-festival surveys
+				surveyHandler.RegisterRoutes(sheddable)
+
+				// Dashboard activity feed
+				activityHandler.RegisterRoutes(sheddable)
+
+				// White-label branding
+				brandingHandler.RegisterRoutes(festivalScoped)
+
+				// Gapless numbering of invoices, receipts and settlements
+				numberingHandler.RegisterRoutes(festivalScoped)
+
+				// Wallet QR offline verification for POS devices, staff only
+				posDevices := festivalScoped.Group("")
+				posDevices.Use(middleware.RequireStaff())
+				walletHandler.RegisterFestivalRoutes(posDevices)
+
+				// Streaming CSV/JSONL exports, organizers only
+				exports := festivalScoped.Group("")
+				exports.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				exportHandler.RegisterRoutes(exports)
+
+				// Stand printers and failed print jobs, organizers only
+				printers := festivalScoped.Group("")
+				printers.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				printingHandler.RegisterRoutes(printers)
+
+				// OAuth client registration, rotation and usage, organizers only
+				oauthClients := festivalScoped.Group("")
+				oauthClients.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				oauthHandler.RegisterRoutes(oauthClients)
+
+				// Revenue and cost budgets with forecasts, organizers only
+				budgets := festivalScoped.Group("")
+				budgets.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				budgetHandler.RegisterRoutes(budgets)
+
+				// Stand owners and vendor payouts, organizers only
+				vendors := festivalScoped.Group("")
+				vendors.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				vendorHandler.RegisterRoutes(vendors)
+
+				// Shift handovers and end-of-day closes, staff only
+				dayCloses := festivalScoped.Group("")
+				dayCloses.Use(middleware.RequireStaff())
+				dayCloseHandler.RegisterRoutes(dayCloses)
+
+				// Adjustments of closed days, organizers only
+				dayCloseAdjustments := festivalScoped.Group("")
+				dayCloseAdjustments.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				dayCloseHandler.RegisterAdjustmentRoutes(dayCloseAdjustments)
+
+				// Attestation chain of the financial documents, organizers only
+				attestations := festivalScoped.Group("")
+				attestations.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				attestationHandler.RegisterRoutes(attestations)
+
+				// Delivery locations, organizers only; runner queue, staff only
+				deliveryHandler.RegisterAttendeeRoutes(festivalScoped)
+				deliveryLocations := festivalScoped.Group("")
+				deliveryLocations.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				deliveryHandler.RegisterRoutes(deliveryLocations)
+				deliveryRunners := festivalScoped.Group("")
+				deliveryRunners.Use(middleware.RequireStaff())
+				deliveryHandler.RegisterRunnerRoutes(deliveryRunners)
+
+				// Custom order fields, defined by organizers and listed for the POS and app
+				orderFieldHandler.RegisterAttendeeRoutes(festivalScoped)
+				orderFields := festivalScoped.Group("")
+				orderFields.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				orderFieldHandler.RegisterRoutes(orderFields)
+
+				// Menu recommendations for the attendee app
+				recommendationHandler.RegisterRoutes(festivalScoped)
+
+				// Order preparation time predictions for the attendee app; models, organizers only
+				etaHandler.RegisterRoutes(festivalScoped)
+				etaModels := festivalScoped.Group("")
+				etaModels.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				etaHandler.RegisterOrganizerRoutes(etaModels)
+
+				// Product recalls, organizers only
+				recalls := festivalScoped.Group("")
+				recalls.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				recallHandler.RegisterRoutes(recalls)
+
+				// Locker zones and inventory, organizers only; rental desk and occupancy,
+				// staff only
+				lockerZones := festivalScoped.Group("")
+				lockerZones.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				lockerHandler.RegisterRoutes(lockerZones)
+				lockerRentals := festivalScoped.Group("")
+				lockerRentals.Use(middleware.RequireStaff())
+				lockerHandler.RegisterStaffRoutes(lockerRentals)
+
+				// Parking and shuttle pass shop for the attendee app; products, sales and
+				// usage, organizers only; gate scans, staff only
+				transportHandler.RegisterAttendeeRoutes(festivalScoped)
+				transportProducts := festivalScoped.Group("")
+				transportProducts.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				transportHandler.RegisterRoutes(transportProducts)
+				transportGates := festivalScoped.Group("")
+				transportGates.Use(middleware.RequireStaff())
+				transportHandler.RegisterStaffRoutes(transportGates)
+
+				// Entry bundle shop for the attendee app; bundles, purchases and refunds,
+				// organizers only
+				bundleHandler.RegisterAttendeeRoutes(festivalScoped)
+				bundleAdmin := festivalScoped.Group("")
+				bundleAdmin.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				bundleHandler.RegisterRoutes(bundleAdmin)
+
+				// Wallet pre-sales for the attendee app; campaigns, pre-sales and the
+				// pre-sale report, organizers only
+				preSaleHandler.RegisterAttendeeRoutes(festivalScoped)
+				preSaleAdmin := festivalScoped.Group("")
+				preSaleAdmin.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				preSaleHandler.RegisterRoutes(preSaleAdmin)
+
+				// Round-up opt-in for the attendee app; round-up program, donation ledger,
+				// report and settlements, organizers only
+				donationHandler.RegisterAttendeeRoutes(festivalScoped)
+				donationAdmin := festivalScoped.Group("")
+				donationAdmin.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				donationHandler.RegisterRoutes(donationAdmin)
+
+				// Sensor devices and thresholds, organizers only; telemetry, alerts and
+				// restock tasks for the stand staff
+				sensorDevices := festivalScoped.Group("")
+				sensorDevices.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				sensorHandler.RegisterRoutes(sensorDevices)
+				sensorTelemetry := festivalScoped.Group("")
+				sensorTelemetry.Use(middleware.RequireStaff())
+				sensorHandler.RegisterStaffRoutes(sensorTelemetry)
+
+				// POS pairing codes and devices, organizers only
+				posDeviceAdmin := festivalScoped.Group("")
+				posDeviceAdmin.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				posDeviceHandler.RegisterRoutes(posDeviceAdmin)
+
+				// Display tokens of the menu boards, organizers only
+				displayTokens := festivalScoped.Group("")
+				displayTokens.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				displayHandler.RegisterRoutes(displayTokens)
+
+				// Occupancy overlay of the festival map for the attendee app
+				occupancyHandler.RegisterAttendeeRoutes(festivalScoped)
+
+				// Delivery latency of the webhooks, organizers only
+				webhookMetrics := festivalScoped.Group("")
+				webhookMetrics.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				webhookHandler.RegisterRoutes(webhookMetrics)
+
+				// Browser origins of the festival web apps, organizers only
+				corsOrigins := festivalScoped.Group("")
+				corsOrigins.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				corsPolicyHandler.RegisterRoutes(corsOrigins)
+
+				// Restock rules and turnaround analytics, organizers only; requests, picking
+				// queue and deliveries for the stand, warehouse and courier staff
+				restockRules := festivalScoped.Group("")
+				restockRules.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				restockHandler.RegisterRoutes(restockRules)
+				restockRequests := festivalScoped.Group("")
+				restockRequests.Use(middleware.RequireStaff())
+				restockHandler.RegisterStaffRoutes(restockRequests)
+
+				// Review of duplicate wallet charges, organizers only
+				duplicateCharges := festivalScoped.Group("")
+				duplicateCharges.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				duplicateChargeHandler.RegisterRoutes(duplicateCharges)
+
+				// Wallet reconciliation reports, organizers only
+				reconciliations := festivalScoped.Group("")
+				reconciliations.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				reconciliationHandler.RegisterRoutes(reconciliations)
+
+				// Auto-cancel rates of unpaid orders, organizers only
+				autoCancellations := festivalScoped.Group("")
+				autoCancellations.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				autoCancelHandler.RegisterRoutes(autoCancellations)
+
+				// Data residency policy and data flow audit, organizers only
+				residencyPolicies := festivalScoped.Group("")
+				residencyPolicies.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				residencyHandler.RegisterRoutes(residencyPolicies)
+
+				// Bulk wallet credits and debits, organizers only
+				walletBatches := festivalScoped.Group("")
+				walletBatches.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				walletBatchHandler.RegisterRoutes(walletBatches)
+
+				// Imports from a previous cashless provider, organizers only
+				legacyImports := festivalScoped.Group("")
+				legacyImports.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				legacyImportHandler.RegisterRoutes(legacyImports)
+
+				// Data retention policies and compliance report, organizers only
+				retentionPolicies := festivalScoped.Group("")
+				retentionPolicies.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				retentionHandler.RegisterRoutes(retentionPolicies)
+
+				// Wallet top-ups by bank transfer and review of unmatched transfers, organizers only
+				bankTransfers := festivalScoped.Group("")
+				bankTransfers.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				bankTransferHandler.RegisterRoutes(bankTransfers)
+
+				// Public stats settings and preview, organizers only
+				publicStats := festivalScoped.Group("")
+				publicStats.Use(middleware.RequireRole(middleware.RoleOrganizer))
+				publicStatsHandler.RegisterRoutes(publicStats)
+
+				// Order and transaction search, support staff holding the support:search
+				// permission only
+				supportSearch := festivalScoped.Group("")
+				supportSearch.Use(middleware.RequireStaff(), middleware.RequireRolePermission(support.PermissionSearch))
+				supportHandler.RegisterSearchRoutes(supportSearch)
+
+				// Test clocks of sandbox festivals, organizers only, never in production
+				if cfg.Environment != "production" {
+					testClocks := festivalScoped.Group("")
+					testClocks.Use(middleware.RequireRole(middleware.RoleOrganizer))
+					testClockHandler.RegisterRoutes(testClocks)
+				}
 			}
 		}
 	}
@@ -278,10 +1308,45 @@ func main() {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
 
+	waitTimeService.Stop()
+	budgetService.Stop()
+	stopRotation()
+	stopAlertRules()
+	stopFailover()
+	securityAuditor.Close()
+	stopSLOs()
+	stopGovernance()
+
 	// Close connections
 	sqlDB, _ := db.DB()
 	sqlDB.Close()
-	rdb.Close()
+	redisClients.Close()
+	queueClient.Close()
 
 	log.Info().Msg("Server exited properly")
 }
+
+// redisClientOptions maps the configured pool settings of a Redis subsystem, its keys
+// prefixed with the namespace of the region
+func redisClientOptions(c config.RedisClientConfig, namespace string) cache.ClientOptions {
+	return cache.ClientOptions{
+		PoolSize:     c.PoolSize,
+		MinIdleConns: c.MinIdleConns,
+		ReadTimeout:  c.ReadTimeout,
+		WriteTimeout: c.WriteTimeout,
+		Namespace:    namespace,
+	}
+}
+
+// newGeoAccessService opens the MaxMind databases and loads the geo access rules
+func newGeoAccessService(cfg *config.Config, db *gorm.DB) (*geoaccess.Service, error) {
+	resolver, err := geoip.NewResolver(cfg.GeoIPCountryDBPath, cfg.GeoIPASNDBPath)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := geoaccess.LoadConfig(cfg.GeoAccessConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return geoaccess.NewService(resolver, geoaccess.NewRepository(db), *rules)
+}
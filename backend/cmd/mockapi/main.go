@@ -0,0 +1,74 @@
+// Command mockapi serves the public API with example responses generated from its
+// OpenAPI document, for the apps and POS devices to be developed without a backend.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/contract"
+	"github.com/mimi6060/festivals/backend/internal/pkg/openapi"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	addr := flag.String("addr", ":4010", "address to listen on")
+	basePath := flag.String("base", contract.BasePath, "path the API is served below")
+	specFile := flag.String("spec", "", "OpenAPI document to serve instead of the built-in public API")
+	latency := flag.Duration("latency", 0, "delay added to every response, e.g. 300ms to mimic a festival network")
+	flag.Parse()
+
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	doc := contract.PublicAPI()
+	if *specFile != "" {
+		data, err := os.ReadFile(*specFile)
+		if err != nil {
+			log.Fatal().Err(err).Str("spec", *specFile).Msg("Failed to read OpenAPI document")
+		}
+		if doc, err = openapi.Parse(data); err != nil {
+			log.Fatal().Err(err).Str("spec", *specFile).Msg("Failed to parse OpenAPI document")
+		}
+	}
+	if err := doc.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid OpenAPI document")
+	}
+
+	mock := openapi.NewMock(doc, *basePath)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		time.Sleep(*latency)
+		mock.ServeHTTP(w, r)
+		log.Info().Str("method", r.Method).Str("path", r.URL.Path).Dur("duration", time.Since(start)).Msg("Request")
+	})
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("addr", *addr).Str("base", *basePath).Str("version", doc.Info.Version).Msg("Mock API listening")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Mock API failed")
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down mock API")
+	}
+}
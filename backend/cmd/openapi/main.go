@@ -0,0 +1,52 @@
+// Command openapi writes the OpenAPI document of the public API generated from the
+// handler types. With -check it fails when the written document is stale instead.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mimi6060/festivals/backend/internal/contract"
+)
+
+func main() {
+	output := flag.String("o", "../docs/api/public-openapi.yaml", "file to write the document to")
+	check := flag.Bool("check", false, "fail if the file differs from the generated document instead of writing it")
+	flag.Parse()
+
+	if err := run(*output, *check); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(output string, check bool) error {
+	doc := contract.PublicAPI()
+	if err := doc.Validate(); err != nil {
+		return fmt.Errorf("invalid OpenAPI document:\n%w", err)
+	}
+
+	data, err := doc.YAML()
+	if err != nil {
+		return err
+	}
+
+	if check {
+		current, err := os.ReadFile(output)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", output, err)
+		}
+		if !bytes.Equal(current, data) {
+			return fmt.Errorf("%s is stale, run make openapi", output)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Printf("Wrote %s\n", output)
+	return nil
+}
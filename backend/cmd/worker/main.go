@@ -9,15 +9,51 @@ import (
 
 	"github.com/hibiken/asynq"
 	"github.com/mimi6060/festivals/backend/internal/config"
+	"github.com/mimi6060/festivals/backend/internal/domain/activity"
+	"github.com/mimi6060/festivals/backend/internal/domain/attestation"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/branding"
+	"github.com/mimi6060/festivals/backend/internal/domain/category"
+	"github.com/mimi6060/festivals/backend/internal/domain/dayclose"
+	"github.com/mimi6060/festivals/backend/internal/domain/duplicatecharge"
+	"github.com/mimi6060/festivals/backend/internal/domain/eta"
+	"github.com/mimi6060/festivals/backend/internal/domain/keyrotation"
+	"github.com/mimi6060/festivals/backend/internal/domain/numbering"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/posdevice"
+	"github.com/mimi6060/festivals/backend/internal/domain/presale"
+	"github.com/mimi6060/festivals/backend/internal/domain/printing"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/domain/recall"
+	"github.com/mimi6060/festivals/backend/internal/domain/recommendation"
+	"github.com/mimi6060/festivals/backend/internal/domain/reconciliation"
 	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/mimi6060/festivals/backend/internal/domain/residency"
+	"github.com/mimi6060/festivals/backend/internal/domain/restock"
+	"github.com/mimi6060/festivals/backend/internal/domain/retention"
+	"github.com/mimi6060/festivals/backend/internal/domain/stats"
+	"github.com/mimi6060/festivals/backend/internal/domain/suppression"
 	"github.com/mimi6060/festivals/backend/internal/domain/sync"
+	"github.com/mimi6060/festivals/backend/internal/domain/testclock"
+	"github.com/mimi6060/festivals/backend/internal/domain/vendorportal"
 	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/domain/walletbatch"
+	"github.com/mimi6060/festivals/backend/internal/domain/walletpass"
+	"github.com/mimi6060/festivals/backend/internal/domain/weather"
+	"github.com/mimi6060/festivals/backend/internal/domain/webhook"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/database"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	stripepay "github.com/mimi6060/festivals/backend/internal/infrastructure/payment"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/qrcode"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/sms"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/storage"
+	weatherprovider "github.com/mimi6060/festivals/backend/internal/infrastructure/weather"
 	"github.com/mimi6060/festivals/backend/internal/jobs"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -37,6 +73,9 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
 
+	// Client IPs are truncated in the logs unless the environment keeps them
+	privacy.SetLogIPAnonymization(cfg.LogAnonymizeIP)
+
 	// Connect to database
 	db, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
@@ -49,17 +88,28 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to Redis")
 	}
+	cache.SetNamespace(rdb, cfg.RedisNamespace)
 	log.Info().Msg("Connected to Redis")
 
+	// Memory budgets of the security audit and analytics keys, shared with the API: the
+	// analytics fall back to aggregates while over budget
+	memoryGovernor := cache.NewMemoryGovernor(rdb, cfg.RedisGovernanceInterval, cache.DefaultKeyBudgets(
+		int64(cfg.RedisAuditMemoryBudgetMB)<<20,
+		int64(cfg.RedisAnalyticsMemoryBudgetMB)<<20,
+	)...)
+	governanceCtx, stopGovernance := context.WithCancel(context.Background())
+	defer stopGovernance()
+	go memoryGovernor.Start(governanceCtx)
+
 	// Initialize storage service
 	var storageService reports.StorageService
 	if cfg.MinioEndpoint != "" {
 		minioStorage, err := storage.NewMinioStorage(storage.MinioConfig{
-			Endpoint:  cfg.MinioEndpoint,
-			AccessKey: cfg.MinioAccessKey,
-			SecretKey: cfg.MinioSecretKey,
-			Bucket:    cfg.MinioBucket,
-			UseSSL:    cfg.Environment == "production",
+			Endpoint:        cfg.MinioEndpoint,
+			AccessKeyID:     cfg.MinioAccessKey,
+			SecretAccessKey: cfg.MinioSecretKey,
+			DefaultBucket:   cfg.MinioBucket,
+			UseSSL:          cfg.Environment == "production",
 		})
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to initialize MinIO storage, falling back to local storage")
@@ -69,6 +119,24 @@ func main() {
 		}
 	}
 
+	// Initialize the EU storage of festivals pinned to the EU
+	var euStorageService reports.StorageService
+	if cfg.MinioEUEndpoint != "" {
+		euStorage, err := storage.NewMinioStorage(storage.MinioConfig{
+			Endpoint:        cfg.MinioEUEndpoint,
+			AccessKeyID:     cfg.MinioEUAccessKey,
+			SecretAccessKey: cfg.MinioEUSecretKey,
+			DefaultBucket:   cfg.MinioEUBucket,
+			UseSSL:          cfg.Environment == "production",
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to initialize EU storage, reports of festivals pinned to the EU will fail")
+		} else {
+			euStorageService = euStorage
+			log.Info().Msg("Connected to EU storage")
+		}
+	}
+
 	// Initialize Twilio SMS client
 	var twilioClient *sms.TwilioClient
 	if cfg.TwilioAccountSID != "" && cfg.TwilioAuthToken != "" {
@@ -77,6 +145,8 @@ func main() {
 			AuthToken:  cfg.TwilioAuthToken,
 			FromNumber: cfg.TwilioFromNumber,
 			RateLimit:  cfg.TwilioRateLimit,
+			Region:     cfg.TwilioRegion,
+			Edge:       cfg.TwilioEdge,
 			Timeout:    30 * time.Second,
 		})
 		log.Info().Msg("Initialized Twilio SMS client")
@@ -84,6 +154,19 @@ func main() {
 		log.Warn().Msg("Twilio not configured, SMS sending will be disabled")
 	}
 
+	// Initialize weather provider
+	weatherProvider, err := weatherprovider.NewProvider(weatherprovider.Config{
+		Provider: cfg.WeatherProvider,
+		BaseURL:  cfg.WeatherAPIURL,
+		APIKey:   cfg.WeatherAPIKey,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize weather provider")
+	}
+	if weatherProvider == nil {
+		log.Warn().Msg("Weather provider disabled, weather ingestion will be skipped")
+	}
+
 	// Initialize asynq client for enqueuing tasks from workers
 	asynqClient, err := queue.NewClient(cfg.RedisURL)
 	if err != nil {
@@ -95,10 +178,85 @@ func main() {
 	walletRepo := wallet.NewRepository(db)
 	reportsRepo := reports.NewRepository(db)
 	syncRepo := sync.NewRepository(db)
+	productRepo := product.NewRepository(db)
+	priceUpdateRepo := product.NewPriceUpdateRepository(db)
+	priceListRepo := product.NewPriceListRepository(db)
+	statsRepo := stats.NewRepository(db)
+	weatherRepo := weather.NewRepository(db)
+	suppressionRepo := suppression.NewRepository(db)
+	brandingRepo := branding.NewRepository(db)
+
+	// Job progress is relayed to the dashboards by the API instances
+	jobPublisher := realtime.NewPublisher(rdb)
 
 	// Initialize services
 	reportsService := reports.NewService(reportsRepo, storageService, asynqClient.Client, "/tmp/festivals/reports")
+	reportsService.SetJobBroadcaster(jobPublisher)
+	residencyService := residency.NewService(residency.NewRepository(db), residency.NewCatalog(residency.CatalogConfig{
+		StorageEndpoint:   cfg.MinioEndpoint,
+		StorageBucket:     cfg.MinioBucket,
+		StorageRegion:     cfg.MinioRegion,
+		EUStorageEndpoint: cfg.MinioEUEndpoint,
+		EUStorageBucket:   cfg.MinioEUBucket,
+		EUStorageRegion:   cfg.MinioEURegion,
+		PostalURL:         cfg.PostalURL,
+		PostalRegion:      cfg.PostalRegion,
+		PostalEUURL:       cfg.PostalEUURL,
+		PostalEURegion:    cfg.PostalEURegion,
+		TwilioEnabled:     twilioClient != nil,
+		TwilioHost:        sms.TwilioHost(cfg.TwilioRegion, cfg.TwilioEdge),
+		TwilioRegion:      cfg.TwilioRegion,
+	}))
+	reportsService.SetResidency(residencyService, euStorageService)
 	syncService := sync.NewService(syncRepo, walletRepo, cfg.JWTSecret)
+	keyring := security.NewKeyring(cfg.JWTSecret, cfg.JWTPreviousSecrets, cfg.JWTKeyOverlap)
+	syncService.SetKeyring(keyring)
+	// Financial exports are sealed in the signed attestation chain of their festival
+	reportsService.SetAttester(attestation.NewService(attestation.NewRepository(db), keyring))
+	priceUpdateService := product.NewPriceUpdateService(priceUpdateRepo, productRepo, asynqClient)
+	priceListService := product.NewPriceListService(priceListRepo, productRepo)
+	// Scheduled jobs run sandbox festivals on a test clock at its virtual time
+	testClockService := testclock.NewService(testclock.NewRepository(db))
+	priceListService.SetClock(testClockService)
+
+	// Wallet, price and product changes of the jobs are counted for the POS terminals
+	posSequencer := posdevice.NewSequencer(posdevice.NewSequenceStore(rdb), posdevice.NewRepository(db))
+	walletService := wallet.NewService(walletRepo, cfg.JWTSecret)
+	walletService.SetChangeSequencer(posSequencer)
+	walletService.SetActivityNotifier(jobs.NewPushQueue(asynqClient))
+	syncService.SetChangeSequencer(posSequencer)
+	priceUpdateService.SetChangeSequencer(posSequencer)
+	priceListService.SetChangeSequencer(posSequencer)
+	statsService := stats.NewService(statsRepo, db)
+	weatherService := weather.NewService(weatherRepo, weatherProvider)
+	suppressionService := suppression.NewService(suppressionRepo, rdb)
+	brandingService := branding.NewService(brandingRepo, rdb)
+	printingService := printing.NewService(printing.NewRepository(db), rdb)
+	walletPassEncryptor, err := walletpass.NewKeyEncryptor(cfg.WalletPassEncryptionKey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize wallet pass key encryption")
+	}
+	walletPassService := walletpass.NewService(
+		walletpass.NewRepository(db),
+		walletPassEncryptor,
+		qrcode.NewGenerator(qrcode.Config{SecretKey: cfg.QRCodeSecret, QRSize: cfg.QRCodeSize}),
+		walletpass.Config{
+			WebServiceURL:    cfg.WalletPassWebServiceURL,
+			OrganizationName: cfg.WalletPassOrganization,
+			Origins:          cfg.WalletPassOrigins,
+		},
+	)
+
+	// Signing secret rotation on SIGHUP or when broadcast by another instance
+	rotationCtx, stopRotation := context.WithCancel(context.Background())
+	defer stopRotation()
+	keyRotationService := keyrotation.NewService(keyring, cfg.LoadJWTSecrets)
+	keyRotationService.SetAuditLogger(audit.NewService(audit.NewRepository(db)))
+	keyRotationService.SetBroadcaster(rdb)
+	if err := keyRotationService.Listen(rotationCtx); err != nil {
+		log.Warn().Err(err).Msg("Key rotations from other instances will not be applied")
+	}
+	go keyRotationService.ReloadOnSignal(rotationCtx)
 
 	// Create asynq server with configuration
 	serverCfg := queue.ServerConfig{
@@ -115,10 +273,85 @@ func main() {
 	// Initialize workers
 	emailWorker := jobs.NewEmailWorker(cfg)
 	smsWorker := jobs.NewSMSWorker(twilioClient)
+	emailWorker.SetSuppressor(suppressionService)
+	emailWorker.SetBrandingProvider(brandingService)
+	smsWorker.SetSuppressor(suppressionService)
+	emailWorker.SetResidencyRouter(residencyService)
+	smsWorker.SetResidencyRouter(residencyService)
 	reportWorker := jobs.NewReportWorker(reportsService)
 	syncWorker := jobs.NewSyncWorker(syncService)
 	cleanupWorker := jobs.NewCleanupWorker(db, rdb, storageService)
 	analyticsWorker := jobs.NewAnalyticsWorker(db, rdb)
+	analyticsWorker.SetDashboardMaterializer(statsService)
+	analyticsWorker.SetStorageBudget(memoryGovernor)
+	analyticsWorker.SetRecommendationRefresher(recommendation.NewService(recommendation.NewRepository(db), rdb, recommendation.DefaultConfig()))
+	analyticsWorker.SetETAModelTrainer(eta.NewService(eta.NewRepository(db), rdb, eta.DefaultConfig()))
+
+	// Duplicate wallet charge detection, reversing duplicates where the festival policy allows it
+	duplicateChargeService := duplicatecharge.NewService(
+		duplicatecharge.NewRepository(db),
+		walletService,
+		duplicatecharge.DefaultConfig(),
+	)
+	duplicateChargeService.SetNotifier(jobs.NewEmailQueue(asynqClient))
+
+	// Refund and notification of the purchasers of recalled products. Refunds go through
+	// the order service, which leaves the orders of closed business days alone.
+	orderService := order.NewService(order.NewRepository(db), productRepo, walletService)
+	orderService.SetClosedDayChecker(dayclose.NewService(
+		dayclose.NewRepository(db),
+		numbering.NewService(numbering.NewRepository(db)),
+		orderService,
+	))
+	recallService := recall.NewService(recall.NewRepository(db), asynqClient)
+	recallService.SetOrderRefunder(orderService)
+	recallService.SetNotifier(jobs.NewEmailQueue(asynqClient))
+	recallService.SetJobBroadcaster(jobPublisher)
+
+	// Vendor menu changes approved for a later publication, published through the product
+	// service as when approved for right away
+	vendorProductService := product.NewService(productRepo)
+	vendorProductService.SetCategoryResolver(category.NewService(category.NewRepository(db)))
+	vendorProductService.SetActivityRecorder(activity.NewService(activity.NewRepository(db), rdb))
+	vendorProductService.SetChangeSequencer(posSequencer)
+	vendorService := vendorportal.NewService(vendorportal.NewRepository(db), vendorProductService, nil)
+	vendorService.SetQueue(asynqClient)
+
+	// Scheduled unfreeze of frozen wallets, notifying their holders
+	walletFreezeService := wallet.NewService(walletRepo, cfg.JWTSecret)
+	walletFreezeService.SetFreezeNotifier(jobs.NewEmailQueue(asynqClient))
+	walletFreezeService.SetClock(testClockService)
+	walletFreezeService.SetChangeSequencer(posSequencer)
+
+	// Nightly activity digests of the wallets whose holders chose them over a push per
+	// transaction
+	walletDigestService := wallet.NewService(walletRepo, cfg.JWTSecret)
+	walletDigestService.SetActivityNotifier(jobs.NewPushQueue(asynqClient))
+	walletDigestService.SetClock(testClockService)
+
+	// Pre-sale top-ups credited when the festival gates open
+	preSaleService := presale.NewService(presale.NewRepository(db), walletService)
+	preSaleService.SetClock(testClockService)
+
+	// Bulk wallet credits and debits, adjusting each wallet through the wallet service
+	walletBatchService := walletbatch.NewService(walletbatch.NewRepository(db), asynqClient)
+	walletBatchService.SetAdjuster(walletService)
+	walletBatchService.SetJobBroadcaster(jobPublisher)
+
+	// Webhook deliveries, retries queued back on the queue of their priority class
+	webhookService := webhook.NewService(webhook.NewRepository(db), webhook.NewSender(webhook.DefaultSenderConfig()), asynqClient, webhook.DefaultServiceConfig())
+
+	// Nightly wallet reconciliation, alerting when a festival drifts above the threshold
+	reconciliationConfig := reconciliation.DefaultConfig()
+	reconciliationConfig.Threshold = cfg.ReconciliationThreshold
+	reconciliationService := reconciliation.NewService(reconciliation.NewRepository(db), reconciliationConfig)
+	reconciliationService.SetAlerter(monitoring.NewWebhookAlerter(cfg.ReconciliationAlertWebhookURL))
+	reconciliationService.SetJobBroadcaster(jobPublisher)
+	if cfg.StripeSecretKey != "" {
+		reconciliationService.SetStripeVerifier(stripepay.NewStripeClient(cfg.StripeSecretKey, cfg.StripeWebhookSecret))
+	} else {
+		log.Warn().Msg("Stripe not configured, reconciliation will not verify payments against Stripe")
+	}
 
 	// Register handlers
 	log.Info().Msg("Registering job handlers...")
@@ -130,6 +363,72 @@ func main() {
 	cleanupWorker.RegisterHandlers(server)
 	analyticsWorker.RegisterHandlers(server)
 
+	// Scheduled product price updates
+	server.HandleFunc(product.TypeApplyPriceUpdate, priceUpdateService.HandleApplyPriceUpdate)
+	server.HandleFunc(product.TypeRevertPriceUpdate, priceUpdateService.HandleRevertPriceUpdate)
+
+	// Scheduled price list activation
+	server.HandleFunc(product.TypeActivatePriceLists, priceListService.HandleActivatePriceLists)
+
+	// Surge staffing recommendations
+	server.HandleFunc(stats.TypeGenerateStaffingRecommendations, statsService.HandleGenerateStaffingRecommendations)
+
+	// Materialized dashboard aggregates
+	server.HandleFunc(stats.TypeMaterializeDashboard, statsService.HandleMaterializeDashboard)
+
+	// Hourly weather ingestion
+	server.HandleFunc(weather.TypeIngestWeather, weatherService.HandleIngestWeather)
+
+	// Apple Wallet and Google Wallet pass updates
+	server.HandleFunc(walletpass.TypeRefreshPasses, walletPassService.HandleRefreshPasses)
+
+	// Print jobs never acknowledged by their agent
+	server.HandleFunc(printing.TypeSweepJobs, printingService.HandleSweepJobs)
+
+	// Duplicate wallet charges
+	server.HandleFunc(duplicatecharge.TypeDetectDuplicates, duplicateChargeService.HandleDetectDuplicates)
+
+	// Purchasers of recalled products
+	server.HandleFunc(recall.TypeNotifyPurchasers, recallService.HandleNotifyPurchasers)
+
+	// Scheduled publication of approved vendor menu changes
+	server.HandleFunc(vendorportal.TypePublishMenuChange, vendorService.HandlePublishMenuChange)
+
+	// Frozen wallets due for their automatic unfreeze
+	server.HandleFunc(wallet.TypeThawWallets, walletFreezeService.HandleThawWallets)
+
+	// Wallet activity digests due, at night in the festival time zone
+	server.HandleFunc(wallet.TypeSendActivityDigests, walletDigestService.HandleSendActivityDigests)
+
+	// Pre-sale top-ups of the festivals whose gates opened
+	server.HandleFunc(presale.TypeActivatePreSales, preSaleService.HandleActivatePreSales)
+
+	// Chunks of bulk wallet credits and debits
+	server.HandleFunc(walletbatch.TypeProcessChunk, walletBatchService.HandleProcessChunk)
+
+	// Webhook deliveries, from the priority, standard and bulk webhook queues
+	server.HandleFunc(webhook.TypeDeliverWebhook, webhookService.HandleDeliverWebhook)
+
+	// Pending orders never paid
+	autoCancelService := order.NewAutoCancelService(order.NewRepository(db))
+	autoCancelService.SetClock(testClockService)
+	server.HandleFunc(order.TypeCancelStaleOrders, autoCancelService.HandleCancelStaleOrders)
+
+	// Products at or below their restock threshold
+	restockService := restock.NewService(restock.NewRepository(db))
+	server.HandleFunc(restock.TypeScanLowStock, restockService.HandleScanLowStock)
+
+	// Client IPs stored past the retention period
+	retentionService := retention.NewService(retention.NewRepository(db), cfg.IPRetentionDays)
+	server.HandleFunc(retention.TypeAnonymizeIPs, retentionService.HandleAnonymizeIPs)
+
+	// Data past the retention of its class, per festival
+	retentionPolicyService := retention.NewPolicyService(retention.NewPolicyRepository(db))
+	server.HandleFunc(retention.TypeEnforcePolicies, retentionPolicyService.HandleEnforcePolicies)
+
+	// Nightly wallet reconciliation
+	server.HandleFunc(reconciliation.TypeReconcileWallets, reconciliationService.HandleReconcileWallets)
+
 	log.Info().Msg("All job handlers registered")
 
 	// Initialize scheduler for periodic tasks
@@ -229,20 +528,20 @@ func registerPeriodicTasks(scheduler *queue.Scheduler) {
 		log.Info().Msg("Registered periodic task: cleanup temp files (every 6 hours)")
 	}
 
-	// Cleanup expired QR codes daily at 3 AM
+	// Cleanup expired QR codes daily at 3 AM UTC
 	cleanupQRCodesTask := asynq.NewTask(queue.TypeCleanupExpiredQRCodes, nil)
 	if _, err := scheduler.RegisterPeriodicTask("0 3 * * *", cleanupQRCodesTask, asynq.Queue(queue.QueueLow)); err != nil {
 		log.Error().Err(err).Msg("Failed to register cleanup QR codes task")
 	} else {
-		log.Info().Msg("Registered periodic task: cleanup expired QR codes (daily at 3 AM)")
+		log.Info().Msg("Registered periodic task: cleanup expired QR codes (daily at 3 AM UTC)")
 	}
 
-	// Archive old transactions weekly on Sunday at 4 AM
+	// Archive old transactions weekly on Sunday at 4 AM UTC
 	archiveTransactionsTask := asynq.NewTask(queue.TypeArchiveOldTransactions, nil)
 	if _, err := scheduler.RegisterPeriodicTask("0 4 * * 0", archiveTransactionsTask, asynq.Queue(queue.QueueLow), asynq.Timeout(2*time.Hour)); err != nil {
 		log.Error().Err(err).Msg("Failed to register archive transactions task")
 	} else {
-		log.Info().Msg("Registered periodic task: archive old transactions (weekly on Sunday at 4 AM)")
+		log.Info().Msg("Registered periodic task: archive old transactions (weekly on Sunday at 4 AM UTC)")
 	}
 
 	// Process analytics aggregation every 15 minutes
@@ -253,28 +552,160 @@ func registerPeriodicTasks(scheduler *queue.Scheduler) {
 		log.Info().Msg("Registered periodic task: process analytics (every 15 minutes)")
 	}
 
-	// Cleanup old reports weekly on Monday at 2 AM
+	// Cleanup old reports weekly on Monday at 2 AM UTC
 	cleanupReportsTask := asynq.NewTask(queue.TypeCleanupOldReports, nil)
 	if _, err := scheduler.RegisterPeriodicTask("0 2 * * 1", cleanupReportsTask, asynq.Queue(queue.QueueLow)); err != nil {
 		log.Error().Err(err).Msg("Failed to register cleanup old reports task")
 	} else {
-		log.Info().Msg("Registered periodic task: cleanup old reports (weekly on Monday at 2 AM)")
+		log.Info().Msg("Registered periodic task: cleanup old reports (weekly on Monday at 2 AM UTC)")
 	}
 
-	// Cleanup inactive wallets monthly on the 1st at 5 AM
+	// Cleanup inactive wallets monthly on the 1st at 5 AM UTC
 	cleanupWalletsTask := asynq.NewTask(queue.TypeCleanupInactiveWallets, nil)
 	if _, err := scheduler.RegisterPeriodicTask("0 5 1 * *", cleanupWalletsTask, asynq.Queue(queue.QueueLow), asynq.Timeout(1*time.Hour)); err != nil {
 		log.Error().Err(err).Msg("Failed to register cleanup inactive wallets task")
 	} else {
-		log.Info().Msg("Registered periodic task: cleanup inactive wallets (monthly on 1st at 5 AM)")
+		log.Info().Msg("Registered periodic task: cleanup inactive wallets (monthly on 1st at 5 AM UTC)")
 	}
 
-	// Daily analytics aggregation at midnight
+	// Daily analytics aggregation checked hourly, so each festival's previous operational
+	// day is aggregated shortly after its day start in the festival's own timezone
 	dailyAnalyticsTask := asynq.NewTask(queue.TypeAggregateAnalytics, nil)
-	if _, err := scheduler.RegisterPeriodicTask("0 0 * * *", dailyAnalyticsTask, asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)); err != nil {
+	if _, err := scheduler.RegisterPeriodicTask("5 * * * *", dailyAnalyticsTask, asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)); err != nil {
 		log.Error().Err(err).Msg("Failed to register daily analytics aggregation task")
 	} else {
-		log.Info().Msg("Registered periodic task: daily analytics aggregation (daily at midnight)")
+		log.Info().Msg("Registered periodic task: daily analytics aggregation (hourly, after the day start of each festival)")
+	}
+
+	// Price list activation every minute, so schedules switch on time
+	priceListTask := asynq.NewTask(product.TypeActivatePriceLists, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", priceListTask, asynq.Queue(queue.QueueCritical), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register price list activation task")
+	} else {
+		log.Info().Msg("Registered periodic task: price list activation (every minute)")
+	}
+
+	// Weather ingestion every hour, shortly after the provider publishes the past hour
+	weatherTask := asynq.NewTask(weather.TypeIngestWeather, nil)
+	if _, err := scheduler.RegisterPeriodicTask("10 * * * *", weatherTask, asynq.Queue(queue.QueueLow)); err != nil {
+		log.Error().Err(err).Msg("Failed to register weather ingestion task")
+	} else {
+		log.Info().Msg("Registered periodic task: weather ingestion (hourly)")
+	}
+
+	// Staffing recommendations daily at 1 AM UTC
+	staffingTask := asynq.NewTask(stats.TypeGenerateStaffingRecommendations, nil)
+	if _, err := scheduler.RegisterPeriodicTask("0 1 * * *", staffingTask, asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register staffing recommendations task")
+	} else {
+		log.Info().Msg("Registered periodic task: staffing recommendations (daily at 1 AM UTC)")
+	}
+
+	// Dashboard aggregate catch-up every minute for festivals without recent events
+	dashboardTask := asynq.NewTask(stats.TypeMaterializeDashboard, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", dashboardTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register dashboard aggregate task")
+	} else {
+		log.Info().Msg("Registered periodic task: dashboard aggregates (every minute)")
+	}
+
+	// Wallet pass updates every minute, pushing balance and schedule changes
+	walletPassTask := asynq.NewTask(walletpass.TypeRefreshPasses, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", walletPassTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register wallet pass update task")
+	} else {
+		log.Info().Msg("Registered periodic task: wallet pass updates (every minute)")
+	}
+
+	// Print jobs whose agent stopped responding are failed every minute
+	printSweepTask := asynq.NewTask(printing.TypeSweepJobs, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", printSweepTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register print job sweep task")
+	} else {
+		log.Info().Msg("Registered periodic task: print job sweep (every minute)")
+	}
+
+	// Duplicate wallet charge scan every minute
+	duplicateChargeTask := asynq.NewTask(duplicatecharge.TypeDetectDuplicates, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", duplicateChargeTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register duplicate charge scan task")
+	} else {
+		log.Info().Msg("Registered periodic task: duplicate charge scan (every minute)")
+	}
+
+	// Automatic wallet unfreeze every minute
+	thawWalletsTask := asynq.NewTask(wallet.TypeThawWallets, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", thawWalletsTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register wallet unfreeze task")
+	} else {
+		log.Info().Msg("Registered periodic task: wallet unfreeze (every minute)")
+	}
+
+	// Wallet activity digests every 5 minutes, sent soon after they are due or after the
+	// quiet hours of their holder
+	activityDigestsTask := asynq.NewTask(wallet.TypeSendActivityDigests, nil)
+	if _, err := scheduler.RegisterPeriodicTask("*/5 * * * *", activityDigestsTask, asynq.Queue(queue.QueueDefault), asynq.Unique(5*time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register wallet activity digest task")
+	} else {
+		log.Info().Msg("Registered periodic task: wallet activity digests (every 5 minutes)")
+	}
+
+	// Pre-sale top-up activation every minute, crediting them soon after the gates open
+	activatePreSalesTask := asynq.NewTask(presale.TypeActivatePreSales, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", activatePreSalesTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register pre-sale activation task")
+	} else {
+		log.Info().Msg("Registered periodic task: pre-sale activation (every minute)")
+	}
+
+	// Stale pending order cancellation every minute, so orders expire close to their TTL
+	staleOrdersTask := asynq.NewTask(order.TypeCancelStaleOrders, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", staleOrdersTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register stale order cancellation task")
+	} else {
+		log.Info().Msg("Registered periodic task: stale pending order cancellation (every minute)")
+	}
+
+	// Low-stock restock requests every minute
+	lowStockTask := asynq.NewTask(restock.TypeScanLowStock, nil)
+	if _, err := scheduler.RegisterPeriodicTask("* * * * *", lowStockTask, asynq.Queue(queue.QueueDefault), asynq.Unique(time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register low stock restock task")
+	} else {
+		log.Info().Msg("Registered periodic task: low stock restock requests (every minute)")
+	}
+
+	// Client IP anonymization daily at 2:30 AM UTC
+	anonymizeIPsTask := asynq.NewTask(retention.TypeAnonymizeIPs, nil)
+	if _, err := scheduler.RegisterPeriodicTask("30 2 * * *", anonymizeIPsTask, asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register IP anonymization task")
+	} else {
+		log.Info().Msg("Registered periodic task: client IP anonymization (daily at 2:30 AM UTC)")
+	}
+
+	// Retention policy enforcement daily at 2:45 AM UTC
+	enforceRetentionTask := asynq.NewTask(retention.TypeEnforcePolicies, nil)
+	if _, err := scheduler.RegisterPeriodicTask("45 2 * * *", enforceRetentionTask, asynq.Queue(queue.QueueLow), asynq.Timeout(time.Hour), asynq.MaxRetry(1)); err != nil {
+		log.Error().Err(err).Msg("Failed to register retention policy task")
+	} else {
+		log.Info().Msg("Registered periodic task: retention policy enforcement (daily at 2:45 AM UTC)")
+	}
+
+	// Wallet reconciliation nightly at 3:30 AM UTC, after the festival days have closed
+	reconcileTask := asynq.NewTask(reconciliation.TypeReconcileWallets, nil)
+	if _, err := scheduler.RegisterPeriodicTask("30 3 * * *", reconcileTask, asynq.Queue(queue.QueueLow), asynq.Timeout(time.Hour), asynq.MaxRetry(1)); err != nil {
+		log.Error().Err(err).Msg("Failed to register wallet reconciliation task")
+	} else {
+		log.Info().Msg("Registered periodic task: wallet reconciliation (daily at 3:30 AM UTC)")
+	}
+
+	// Dashboard aggregate rebuild daily at 4 AM UTC, picking up late order voids
+	dashboardRebuildTask, err := stats.NewMaterializeDashboardTask(stats.MaterializeDashboardPayload{Rebuild: true})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create dashboard rebuild task")
+	} else if _, err := scheduler.RegisterPeriodicTask("0 4 * * *", dashboardRebuildTask, asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)); err != nil {
+		log.Error().Err(err).Msg("Failed to register dashboard rebuild task")
+	} else {
+		log.Info().Msg("Registered periodic task: dashboard aggregate rebuild (daily at 4 AM UTC)")
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -30,17 +31,44 @@ type Config struct {
 	Environment string
 
 	// Database
-	DatabaseURL string
+	DatabaseURL          string
+	SlowQueryThreshold   time.Duration // Statements running longer are recorded; 0 disables the detection
+	SlowQueryExplainRate float64       // Share of the new worst runs of a SELECT captured with EXPLAIN ANALYZE
 
 	// Redis
-	RedisURL string
+	RedisURL       string
+	RedisCache     RedisClientConfig
+	RedisRateLimit RedisClientConfig
+	RedisSessions  RedisClientConfig
+	RedisRealtime  RedisClientConfig
+
+	// Memory budgets of the Redis keys growing with the traffic; writers sample or
+	// aggregate above them
+	RedisAuditMemoryBudgetMB     int
+	RedisAnalyticsMemoryBudgetMB int
+	RedisGovernanceInterval      time.Duration // Between two sweeps measuring the keys and enforcing their TTL
+
+	// Load shedding of the non-critical routes while the background queues or the
+	// database fall behind
+	BackpressureInterval        time.Duration // Between two checks of the queue backlog and the database p99
+	BackpressureMaxQueueBacklog int           // Tasks waiting in the queues above which load is shed; 0 ignores the queues
+	BackpressureMaxDBP99        time.Duration // Database p99 above which load is shed; 0 ignores the database
+	BackpressureRetryAfter      time.Duration // Sent with the shed requests
+
+	// Multi-region failover: a primary region and a warm standby replicating its database
+	Region            string        // e.g. eu-west; empty when running a single region
+	RedisNamespace    string        // Prefix of the Redis keys, so that regions sharing Redis never collide; the region by default
+	MaxReplicationLag time.Duration // Above it replication is degraded and promotion needs force
 
 	// Auth0
 	Auth0Domain   string
 	Auth0Audience string
 
 	// JWT/Security
-	JWTSecret string
+	JWTSecret          string
+	JWTPreviousSecrets []string      // Still accepted for verification during the overlap window
+	JWTSecretsFile     string        // Current secret on the first line, previous ones below; re-read on reload
+	JWTKeyOverlap      time.Duration // How long a replaced secret stays accepted
 
 	// QR Code
 	QRCodeSecret        string
@@ -49,34 +77,66 @@ type Config struct {
 
 	// CORS
 	CORSAllowedOrigins []string // Allowed origins for CORS
+	CORSMaxAge         int      // Seconds browsers cache preflight responses
 
 	// Stripe
 	StripeSecretKey      string
 	StripeWebhookSecret  string
 	StripePlatformFee    int64 // Platform fee in basis points (100 = 1%)
 
+	// Bank transfer top-ups: the collection account the transfers are paid to, and the
+	// optional provider opening a virtual IBAN per order
+	BankTransferAccountHolder string
+	BankTransferIBAN          string
+	BankTransferBIC           string
+	BankTransferValidity      time.Duration // How long a top-up order waits for its transfer
+	VirtualIBANAPIURL         string
+	VirtualIBANAPIKey         string
+	VirtualIBANWebhookSecret  string // Shared token expected from the provider webhook
+
 	// Storage
 	MinioEndpoint  string
 	MinioAccessKey string
 	MinioSecretKey string
 	MinioBucket    string
+	MinioRegion    string
+
+	// Storage - EU (for festivals pinned to the EU when the default storage is not).
+	// Regions are declared, a provider without one is treated as outside the EU.
+	MinioEUEndpoint  string
+	MinioEUAccessKey string
+	MinioEUSecretKey string
+	MinioEUBucket    string
+	MinioEURegion    string
 
 	// Mail - Postal (Primary)
-	PostalURL    string
-	PostalAPIKey string
+	PostalURL      string
+	PostalAPIKey   string
+	PostalRegion   string
+	PostalEUURL    string // Postal server in the EU, for festivals pinned to the EU
+	PostalEUAPIKey string
+	PostalEURegion string
 
 	// Mail - SendGrid (Fallback)
 	SendGridAPIKey string
 
 	// Mail - Common
-	EmailFromAddress string
-	EmailFromName    string
+	EmailFromAddress   string
+	EmailFromName      string
+	EmailWebhookSecret string // Shared token expected from bounce and complaint webhooks
 
 	// Twilio SMS
 	TwilioAccountSID string
 	TwilioAuthToken  string
 	TwilioFromNumber string
-	TwilioRateLimit  int // Messages per second, 0 for no limit
+	TwilioRateLimit  int    // Messages per second, 0 for no limit
+	TwilioRegion     string // Processing region, e.g. ie1; US when empty
+	TwilioEdge       string // Edge location of the region, e.g. dublin
+
+	// Weather
+	WeatherProvider string // open-meteo or none
+	WeatherAPIURL   string
+	WeatherAPIKey   string
 
 	// OpenAI
 	OpenAIAPIKey     string
@@ -88,6 +148,58 @@ type Config struct {
 	SecurityAlertEmails []string
 	AlertWebhookURLs    []string
 	AlertWebhookSecret  string
+	IncidentConfigPath  string // YAML file routing alerts to PagerDuty or Opsgenie
+
+	// Service Level Objectives
+	SLOConfigPath      string // YAML file defining the endpoint SLOs
+	SLOAlertWebhookURL string // Webhook receiving SLO burn-rate alerts
+
+	// Nightly wallet reconciliation
+	ReconciliationAlertWebhookURL string // Webhook receiving reconciliation drift alerts
+	ReconciliationThreshold       int64  // Drift in cents above which an alert is fired
+
+	// Bulk wallet credits and debits
+	WalletBatchApprovalThreshold int64 // Total in cents from which a batch needs a second organizer's approval; 0 disables
+
+	// Personal data in logs and records
+	LogAnonymizeIP  bool // Truncate client IPs in the logs
+	IPRetentionDays int  // Days stored client IPs are kept in full before being truncated; 0 keeps them
+
+	// Decoy endpoints blocking the scanners requesting them
+	HoneypotEnabled         bool
+	HoneypotBlockTTL        time.Duration // How long the client of a decoy endpoint stays blocked
+	HoneypotTrustedNetworks []string      // CIDRs never blocked, e.g. the office or the uptime monitors
+
+	// Geo-IP and ASN access rules, enabled when a database is set
+	GeoIPCountryDBPath  string // MaxMind GeoLite2-Country or GeoIP2-Country database
+	GeoIPASNDBPath      string // MaxMind GeoLite2-ASN database
+	GeoAccessConfigPath string // YAML file defining the geo access rules
+
+	// Signed POS device requests, protecting wallet debits from replay
+	RequestSigningRequired bool          // Reject the unsigned requests of the devices
+	RequestSigningMaxSkew  time.Duration // How far the timestamp of a signed request may be from now
+
+	// Apple Wallet / Google Wallet passes
+	WalletPassWebServiceURL string   // Public URL of the Apple Wallet web service, ending in /api/v1/passes
+	WalletPassEncryptionKey string   // Base64 32-byte key encrypting the private keys of the signing certificates
+	WalletPassOrganization  string   // Organization name shown on the passes
+	WalletPassOrigins       []string // Web origins allowed to show "Add to Google Wallet" buttons
+
+	// OpenID Connect SSO of the organizations
+	SSOCallbackURL     string   // Public URL of the callback, ending in /api/v1/auth/sso/callback
+	SSOEncryptionKey   string   // Base64 32-byte key encrypting the client secrets of the connections
+	SSORedirectOrigins []string // Frontend origins users are sent back to after signing in
+
+	// Magic link sign-in of the attendees
+	MagicLinkURL string // Page of the frontend the sign-in links open
+}
+
+// RedisClientConfig tunes the connection pool of one Redis subsystem client
+type RedisClientConfig struct {
+	PoolSize     int
+	MinIdleConns int
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
 }
 
 func Load() (*Config, error) {
@@ -102,11 +214,27 @@ func Load() (*Config, error) {
 	qrcodeSecret := os.Getenv("QRCODE_SECRET")
 	databaseURL := os.Getenv("DATABASE_URL")
 
+	// A secrets file takes precedence so that it can be rotated without a restart
+	jwtPreviousSecrets := getEnvStringSlice("JWT_PREVIOUS_SECRETS", nil)
+	jwtSecretsFile := getEnv("JWT_SECRETS_FILE", "")
+	if jwtSecretsFile != "" {
+		current, previous, err := ReadSecretsFile(jwtSecretsFile)
+		if err != nil {
+			return nil, err
+		}
+		jwtSecret, jwtPreviousSecrets = current, previous
+	}
+
 	// SECURITY: Validate required secrets in production
 	if isProduction {
 		if err := validateRequiredSecret("JWT_SECRET", jwtSecret); err != nil {
 			return nil, err
 		}
+		for _, previous := range jwtPreviousSecrets {
+			if err := validateRequiredSecret("JWT_PREVIOUS_SECRETS", previous); err != nil {
+				return nil, err
+			}
+		}
 		if err := validateRequiredSecret("QRCODE_SECRET", qrcodeSecret); err != nil {
 			return nil, err
 		}
@@ -130,23 +258,56 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// GDPR defaults: production and staging truncate the IPs in the logs and keep full
+	// IPs in the records for 30 days; development logs full IPs and keeps them 7 days
+	ipRetentionDays := 7
+	if isProduction {
+		ipRetentionDays = 30
+	}
+
 	return &Config{
 		// Server
 		Port:        getEnv("PORT", "8080"),
 		Environment: environment,
 
 		// Database
-		DatabaseURL: databaseURL,
-
-		// Redis
-		RedisURL: getEnv("REDIS_URL", "redis://localhost:6379"),
+		DatabaseURL:          databaseURL,
+		SlowQueryThreshold:   getEnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		SlowQueryExplainRate: getEnvFloat("SLOW_QUERY_EXPLAIN_RATE", 0.1),
+
+		// Redis - rate limiting runs on every request, so it gets a large pool and short
+		// timeouts to fail open quickly; realtime mostly holds pub/sub connections
+		RedisURL:       getEnv("REDIS_URL", "redis://localhost:6379"),
+		RedisCache:     loadRedisClientConfig("REDIS_CACHE", 20, 5, 500*time.Millisecond),
+		RedisRateLimit: loadRedisClientConfig("REDIS_RATELIMIT", 50, 10, 100*time.Millisecond),
+		RedisSessions:  loadRedisClientConfig("REDIS_SESSIONS", 20, 5, 250*time.Millisecond),
+		RedisRealtime:  loadRedisClientConfig("REDIS_REALTIME", 10, 2, 3*time.Second),
+
+		// Redis memory governance
+		RedisAuditMemoryBudgetMB:     getEnvInt("REDIS_AUDIT_MEMORY_BUDGET_MB", 256),
+		RedisAnalyticsMemoryBudgetMB: getEnvInt("REDIS_ANALYTICS_MEMORY_BUDGET_MB", 128),
+		RedisGovernanceInterval:      getEnvDuration("REDIS_GOVERNANCE_INTERVAL", 5*time.Minute),
+
+		// Load shedding
+		BackpressureInterval:        getEnvDuration("BACKPRESSURE_INTERVAL", 5*time.Second),
+		BackpressureMaxQueueBacklog: getEnvInt("BACKPRESSURE_MAX_QUEUE_BACKLOG", 10000),
+		BackpressureMaxDBP99:        getEnvDuration("BACKPRESSURE_MAX_DB_P99", 500*time.Millisecond),
+		BackpressureRetryAfter:      getEnvDuration("BACKPRESSURE_RETRY_AFTER", 30*time.Second),
+
+		// Multi-region failover
+		Region:            getEnv("REGION", ""),
+		RedisNamespace:    getEnv("REDIS_NAMESPACE", getEnv("REGION", "")),
+		MaxReplicationLag: getEnvDuration("MAX_REPLICATION_LAG", 30*time.Second),
 
 		// Auth0
 		Auth0Domain:   getEnv("AUTH0_DOMAIN", ""),
 		Auth0Audience: getEnv("AUTH0_AUDIENCE", ""),
 
 		// JWT/Security - No insecure defaults
-		JWTSecret: jwtSecret,
+		JWTSecret:          jwtSecret,
+		JWTPreviousSecrets: jwtPreviousSecrets,
+		JWTSecretsFile:     jwtSecretsFile,
+		JWTKeyOverlap:      getEnvDuration("JWT_KEY_OVERLAP", 24*time.Hour),
 
 		// QR Code - No insecure defaults
 		QRCodeSecret:        qrcodeSecret,
@@ -155,34 +316,64 @@ func Load() (*Config, error) {
 
 		// CORS
 		CORSAllowedOrigins: getEnvStringSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:3001"}),
+		CORSMaxAge:         getEnvInt("CORS_MAX_AGE", 600),
 
 		// Stripe
 		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
 		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
 		StripePlatformFee:   int64(getEnvInt("STRIPE_PLATFORM_FEE", 100)), // Default 1%
 
+		// Bank transfer top-ups
+		BankTransferAccountHolder: getEnv("BANK_TRANSFER_ACCOUNT_HOLDER", ""),
+		BankTransferIBAN:          getEnv("BANK_TRANSFER_IBAN", ""),
+		BankTransferBIC:           getEnv("BANK_TRANSFER_BIC", ""),
+		BankTransferValidity:      getEnvDuration("BANK_TRANSFER_VALIDITY", 14*24*time.Hour),
+		VirtualIBANAPIURL:         getEnv("VIRTUAL_IBAN_API_URL", ""),
+		VirtualIBANAPIKey:         getEnv("VIRTUAL_IBAN_API_KEY", ""),
+		VirtualIBANWebhookSecret:  getEnv("VIRTUAL_IBAN_WEBHOOK_SECRET", ""),
+
 		// Storage
 		MinioEndpoint:  getEnv("MINIO_ENDPOINT", "localhost:9000"),
 		MinioAccessKey: getEnv("MINIO_ACCESS_KEY", "minio"),
 		MinioSecretKey: getEnv("MINIO_SECRET_KEY", "minio123"),
 		MinioBucket:    getEnv("MINIO_BUCKET", "festivals"),
+		MinioRegion:    getEnv("MINIO_REGION", "us-east-1"),
+
+		// Storage - EU
+		MinioEUEndpoint:  getEnv("MINIO_EU_ENDPOINT", ""),
+		MinioEUAccessKey: getEnv("MINIO_EU_ACCESS_KEY", ""),
+		MinioEUSecretKey: getEnv("MINIO_EU_SECRET_KEY", ""),
+		MinioEUBucket:    getEnv("MINIO_EU_BUCKET", "festivals-eu"),
+		MinioEURegion:    getEnv("MINIO_EU_REGION", ""),
 
 		// Mail - Postal (Primary)
-		PostalURL:    getEnv("POSTAL_URL", "http://localhost:5000"),
-		PostalAPIKey: getEnv("POSTAL_API_KEY", ""),
+		PostalURL:      getEnv("POSTAL_URL", "http://localhost:5000"),
+		PostalAPIKey:   getEnv("POSTAL_API_KEY", ""),
+		PostalRegion:   getEnv("POSTAL_REGION", ""),
+		PostalEUURL:    getEnv("POSTAL_EU_URL", ""),
+		PostalEUAPIKey: getEnv("POSTAL_EU_API_KEY", ""),
+		PostalEURegion: getEnv("POSTAL_EU_REGION", ""),
 
 		// Mail - SendGrid (Fallback)
 		SendGridAPIKey: getEnv("SENDGRID_API_KEY", ""),
 
 		// Mail - Common
-		EmailFromAddress: getEnv("EMAIL_FROM_ADDRESS", "noreply@festivals.app"),
-		EmailFromName:    getEnv("EMAIL_FROM_NAME", "Festivals"),
+		EmailFromAddress:   getEnv("EMAIL_FROM_ADDRESS", "noreply@festivals.app"),
+		EmailFromName:      getEnv("EMAIL_FROM_NAME", "Festivals"),
+		EmailWebhookSecret: getEnv("EMAIL_WEBHOOK_SECRET", ""),
 
 		// Twilio SMS
 		TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
 		TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
 		TwilioFromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
 		TwilioRateLimit:  getEnvInt("TWILIO_RATE_LIMIT", 10),
+		TwilioRegion:     getEnv("TWILIO_REGION", ""),
+		TwilioEdge:       getEnv("TWILIO_EDGE", ""),
+
+		// Weather
+		WeatherProvider: getEnv("WEATHER_PROVIDER", "open-meteo"),
+		WeatherAPIURL:   getEnv("WEATHER_API_URL", ""),
+		WeatherAPIKey:   getEnv("WEATHER_API_KEY", ""),
 
 		// OpenAI
 		OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
@@ -194,9 +385,95 @@ func Load() (*Config, error) {
 		SecurityAlertEmails: getEnvStringSlice("SECURITY_ALERT_EMAILS", nil),
 		AlertWebhookURLs:    getEnvStringSlice("ALERT_WEBHOOK_URLS", nil),
 		AlertWebhookSecret:  getEnv("ALERT_WEBHOOK_SECRET", ""),
+		IncidentConfigPath:  getEnv("INCIDENT_CONFIG_PATH", "internal/config/incidents.yaml"),
+
+		// Service Level Objectives
+		SLOConfigPath:      getEnv("SLO_CONFIG_PATH", "internal/config/slo.yaml"),
+		SLOAlertWebhookURL: getEnv("SLO_ALERT_WEBHOOK_URL", ""),
+
+		// Nightly wallet reconciliation
+		ReconciliationAlertWebhookURL: getEnv("RECONCILIATION_ALERT_WEBHOOK_URL", ""),
+		ReconciliationThreshold:       int64(getEnvInt("RECONCILIATION_THRESHOLD", 100)), // Default 1.00 in cents
+
+		// Bulk wallet credits and debits
+		WalletBatchApprovalThreshold: int64(getEnvInt("WALLET_BATCH_APPROVAL_THRESHOLD", 100000)), // Default 1,000.00 in cents
+
+		// Personal data in logs and records
+		LogAnonymizeIP:  getEnvBool("LOG_ANONYMIZE_IP", isProduction),
+		IPRetentionDays: getEnvInt("IP_RETENTION_DAYS", ipRetentionDays),
+
+		// Decoy endpoints blocking the scanners requesting them
+		HoneypotEnabled:         getEnvBool("HONEYPOT_ENABLED", true),
+		HoneypotBlockTTL:        getEnvDuration("HONEYPOT_BLOCK_TTL", 24*time.Hour),
+		HoneypotTrustedNetworks: getEnvStringSlice("HONEYPOT_TRUSTED_NETWORKS", nil),
+
+		// Geo-IP and ASN access rules
+		GeoIPCountryDBPath:  getEnv("GEOIP_COUNTRY_DB", ""),
+		GeoIPASNDBPath:      getEnv("GEOIP_ASN_DB", ""),
+		GeoAccessConfigPath: getEnv("GEO_ACCESS_CONFIG_PATH", "internal/config/geoaccess.yaml"),
+
+		// Signed POS device requests
+		RequestSigningRequired: getEnvBool("REQUEST_SIGNING_REQUIRED", false),
+		RequestSigningMaxSkew:  getEnvDuration("REQUEST_SIGNING_MAX_SKEW", 5*time.Minute),
+
+		// Apple Wallet / Google Wallet passes
+		WalletPassWebServiceURL: getEnv("WALLET_PASS_WEB_SERVICE_URL", ""),
+		WalletPassEncryptionKey: os.Getenv("WALLET_PASS_ENCRYPTION_KEY"),
+		WalletPassOrganization:  getEnv("WALLET_PASS_ORGANIZATION", "Festivals"),
+		WalletPassOrigins:       getEnvStringSlice("WALLET_PASS_ORIGINS", nil),
+
+		// OpenID Connect SSO
+		SSOCallbackURL:     getEnv("SSO_CALLBACK_URL", "http://localhost:8080/api/v1/auth/sso/callback"),
+		SSOEncryptionKey:   os.Getenv("SSO_ENCRYPTION_KEY"),
+		SSORedirectOrigins: getEnvStringSlice("SSO_REDIRECT_ORIGINS", []string{"http://localhost:3000"}),
+
+		// Magic links
+		MagicLinkURL: getEnv("MAGIC_LINK_URL", "http://localhost:3000/login/link"),
 	}, nil
 }
 
+// LoadJWTSecrets returns the current and previous JWT secrets, re-reading
+// JWT_SECRETS_FILE when it is configured
+func (c *Config) LoadJWTSecrets() (string, []string, error) {
+	if c.JWTSecretsFile == "" {
+		return c.JWTSecret, c.JWTPreviousSecrets, nil
+	}
+
+	current, previous, err := ReadSecretsFile(c.JWTSecretsFile)
+	if err != nil {
+		return "", nil, err
+	}
+	if c.Environment == "production" || c.Environment == "staging" {
+		for _, secret := range append([]string{current}, previous...) {
+			if err := validateRequiredSecret("JWT_SECRETS_FILE", secret); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	return current, previous, nil
+}
+
+// ReadSecretsFile reads a secrets file holding one secret per line, the current one
+// first. Blank lines and lines starting with # are ignored.
+func ReadSecretsFile(path string) (string, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var secrets []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = trimWhitespace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			secrets = append(secrets, line)
+		}
+	}
+	if len(secrets) == 0 {
+		return "", nil, fmt.Errorf("%w: secrets file %s is empty", ErrMissingSecret, path)
+	}
+	return secrets[0], secrets[1:], nil
+}
+
 // validateRequiredSecret validates that a secret is present and meets security requirements
 func validateRequiredSecret(name, value string) error {
 	if value == "" {
@@ -252,6 +529,35 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// loadRedisClientConfig reads <prefix>_POOL_SIZE, <prefix>_MIN_IDLE_CONNS,
+// <prefix>_READ_TIMEOUT and <prefix>_WRITE_TIMEOUT
+func loadRedisClientConfig(prefix string, poolSize, minIdleConns int, timeout time.Duration) RedisClientConfig {
+	return RedisClientConfig{
+		PoolSize:     getEnvInt(prefix+"_POOL_SIZE", poolSize),
+		MinIdleConns: getEnvInt(prefix+"_MIN_IDLE_CONNS", minIdleConns),
+		ReadTimeout:  getEnvDuration(prefix+"_READ_TIMEOUT", timeout),
+		WriteTimeout: getEnvDuration(prefix+"_WRITE_TIMEOUT", timeout),
+	}
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -0,0 +1,376 @@
+// Package contract describes the public API used by the attendee apps and the POS
+// devices as an OpenAPI document generated from the request and response types of
+// the handlers. The contract tests assert the handlers serve it, cmd/openapi writes
+// it to docs/api/public-openapi.yaml and cmd/mockapi serves it to develop offline.
+package contract
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/festival"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/pkg/openapi"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// Version is the version of the public API contract; bump it on breaking changes
+const Version = "1.0.0"
+
+// BasePath is the path the public API is served below
+const BasePath = "/api/v1"
+
+// ValidateQRRequest is the body of POST /payments/validate-qr, bound inline by the handler
+type ValidateQRRequest struct {
+	QRCode string `json:"qrCode" binding:"required"`
+}
+
+// Operation is an operation of the public API
+type Operation struct {
+	Method  string
+	Path    string // OpenAPI template below BasePath, e.g. /wallets/{id}
+	ID      string
+	Tag     string
+	Summary string
+	Query   []openapi.Parameter
+	Request any // Request body, nil when the operation has none
+	Status  int // Success status
+	Data    any // Data of the success envelope, nil for an empty response
+	List    bool
+	Errors  []int
+}
+
+// Operations returns the operations of the public API
+func Operations() []Operation {
+	pagination := []openapi.Parameter{
+		query("page", "integer", "Page number, from 1"),
+		query("per_page", "integer", "Items per page"),
+	}
+	lang := query("lang", "string", "Comma-separated languages to localize names and descriptions in, before Accept-Language")
+	menuFilter := []openapi.Parameter{
+		query("allergenFree", "string", "Comma-separated allergens the products must be declared free of"),
+		query("dietary", "string", "Comma-separated dietary tags the products must all have"),
+	}
+
+	return []Operation{
+		{
+			Method: http.MethodGet, Path: "/festivals", ID: "listFestivals", Tag: "festivals",
+			Summary: "List festivals", Query: pagination,
+			Status: http.StatusOK, Data: []festival.FestivalResponse{}, List: true,
+			Errors: []int{http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{id}", ID: "getFestival", Tag: "festivals",
+			Summary: "Get a festival",
+			Status:  http.StatusOK, Data: festival.FestivalResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+
+		{
+			Method: http.MethodGet, Path: "/me/wallets", ID: "listMyWallets", Tag: "wallets",
+			Summary: "List the wallets of the current user",
+			Status:  http.StatusOK, Data: []wallet.WalletResponse{},
+			Errors: []int{http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/me/wallets/{festivalId}", ID: "getMyWallet", Tag: "wallets",
+			Summary: "Get the wallet of the current user for a festival, creating it if needed",
+			Status:  http.StatusOK, Data: wallet.WalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodPost, Path: "/me/wallets/{festivalId}", ID: "createMyWallet", Tag: "wallets",
+			Summary: "Create the wallet of the current user for a festival",
+			Status:  http.StatusCreated, Data: wallet.WalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/me/wallets/{festivalId}/transactions", ID: "listMyTransactions", Tag: "wallets",
+			Summary: "List the transactions of the wallet of the current user", Query: pagination,
+			Status: http.StatusOK, Data: []wallet.TransactionResponse{}, List: true,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/me/wallets/{festivalId}/activity", ID: "listMyActivity", Tag: "wallets",
+			Summary: "List the activity of the wallet of the current user, with refunds grouped with their purchase",
+			Query: append([]openapi.Parameter{
+				query("from", "string", "Start of the period, RFC 3339 or YYYY-MM-DD"),
+				query("to", "string", "End of the period, RFC 3339 or YYYY-MM-DD, inclusive"),
+			}, pagination...),
+			Status: http.StatusOK, Data: []wallet.ActivityItem{}, List: true,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodPut, Path: "/me/wallets/{festivalId}/activity-push", ID: "updateMyActivityPush", Tag: "wallets",
+			Summary: "Choose a push per transaction, a nightly digest or no notifications of the wallet activity",
+			Request: wallet.UpdateActivityPushRequest{},
+			Status:  http.StatusOK, Data: wallet.WalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodPost, Path: "/me/wallets/claim", ID: "claimWallet", Tag: "wallets",
+			Summary: "Attach an anonymous wallet to the current user with its claim code",
+			Request: wallet.ClaimWalletRequest{},
+			Status:  http.StatusOK, Data: wallet.WalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict},
+		},
+		{
+			Method: http.MethodGet, Path: "/wallets/{id}", ID: "getWallet", Tag: "wallets",
+			Summary: "Get a wallet",
+			Status:  http.StatusOK, Data: wallet.WalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodPost, Path: "/wallets/{id}/topup", ID: "topUpWallet", Tag: "wallets",
+			Summary: "Top up a wallet", Request: wallet.TopUpRequest{},
+			Status: http.StatusOK, Data: wallet.TransactionResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodPost, Path: "/wallets/anonymous", ID: "createAnonymousWallet", Tag: "wallets",
+			Summary: "Sell a wallet without a user account, funded with cash", Request: wallet.CreateAnonymousWalletRequest{},
+			Status: http.StatusCreated, Data: wallet.AnonymousWalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+
+		{
+			Method: http.MethodPost, Path: "/payments", ID: "createPayment", Tag: "payments",
+			Summary: "Pay at a stand with a wallet", Request: wallet.PaymentRequest{},
+			Status: http.StatusOK, Data: wallet.TransactionResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodPost, Path: "/payments/validate-qr", ID: "validateQR", Tag: "payments",
+			Summary: "Validate a wallet QR code", Request: ValidateQRRequest{},
+			Status: http.StatusOK, Data: wallet.WalletResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodPost, Path: "/payments/refund", ID: "refundPayment", Tag: "payments",
+			Summary: "Refund a payment", Request: wallet.RefundRequest{},
+			Status: http.StatusOK, Data: wallet.TransactionResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/wallet-qr/offline-spec", ID: "getQROfflineSpec", Tag: "payments",
+			Summary: "Get what POS devices need to verify wallet QR codes offline",
+			Status:  http.StatusOK, Data: wallet.QROfflineSpec{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+		},
+
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/stands", ID: "listStands", Tag: "stands",
+			Summary: "List the stands of a festival",
+			Query: append([]openapi.Parameter{
+				query("category", "string", "Only stands of this category"),
+				query("lat", "number", "Latitude of the caller, to return distances"),
+				query("lng", "number", "Longitude of the caller, to return distances"),
+				lang,
+			}, pagination...),
+			Status: http.StatusOK, Data: []stand.StandResponse{}, List: true,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/stands/nearby", ID: "listNearbyStands", Tag: "stands",
+			Summary: "List the stands near a position",
+			Query: []openapi.Parameter{
+				required(query("lat", "number", "Latitude of the caller")),
+				required(query("lng", "number", "Longitude of the caller")),
+				query("radius", "number", "Radius in meters"),
+				query("open_now", "boolean", "Only stands open at the festival's local time"),
+				query("limit", "integer", "Maximum number of stands"),
+				lang,
+			},
+			Status: http.StatusOK, Data: []stand.StandResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/stands/{id}", ID: "getStand", Tag: "stands",
+			Summary: "Get a stand", Query: []openapi.Parameter{lang},
+			Status: http.StatusOK, Data: stand.StandResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/me/stands", ID: "listMyStands", Tag: "stands",
+			Summary: "List the stand assignments of the current staff member",
+			Status:  http.StatusOK, Data: []stand.StandStaffResponse{},
+			Errors: []int{http.StatusUnauthorized},
+		},
+
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/products", ID: "listProducts", Tag: "products",
+			Summary: "List the products of a stand",
+			Query: append([]openapi.Parameter{
+				required(query("standId", "string", "Stand of the products")),
+				query("category", "string", "Only products of this category"),
+				lang,
+			}, append(menuFilter, pagination...)...),
+			Status: http.StatusOK, Data: []product.ProductResponse{}, List: true,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/products/{id}", ID: "getProduct", Tag: "products",
+			Summary: "Get a product", Query: []openapi.Parameter{lang},
+			Status: http.StatusOK, Data: product.ProductResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/festivals/{festivalId}/stands/{id}/products", ID: "listStandProducts", Tag: "products",
+			Summary: "List the products of a stand", Query: append(append([]openapi.Parameter{lang}, menuFilter...), pagination...),
+			Status: http.StatusOK, Data: []product.ProductResponse{}, List: true,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+	}
+}
+
+// PublicAPI returns the OpenAPI document of the public API
+func PublicAPI() *openapi.Document {
+	schemas := openapi.NewSchemas()
+	schemas.Enum(festival.FestivalStatus(""), string(festival.FestivalStatusDraft), string(festival.FestivalStatusActive),
+		string(festival.FestivalStatusCompleted), string(festival.FestivalStatusArchived))
+	schemas.Enum(wallet.WalletStatus(""), string(wallet.WalletStatusActive), string(wallet.WalletStatusFrozen),
+		string(wallet.WalletStatusClosed))
+	schemas.Enum(wallet.TransactionType(""), string(wallet.TransactionTypeTopUp), string(wallet.TransactionTypeCashIn),
+		string(wallet.TransactionTypePurchase), string(wallet.TransactionTypeRefund), string(wallet.TransactionTypeTransfer),
+		string(wallet.TransactionTypeCashOut), string(wallet.TransactionTypeMerge), string(wallet.TransactionTypeAdjustment),
+		string(wallet.TransactionTypeDonation))
+	schemas.Enum(wallet.TransactionStatus(""), string(wallet.TransactionStatusPending), string(wallet.TransactionStatusCompleted),
+		string(wallet.TransactionStatusFailed), string(wallet.TransactionStatusRefunded))
+	schemas.Enum(wallet.ActivityStatus(""), string(wallet.ActivityStatusCompleted), string(wallet.ActivityStatusPartiallyRefunded),
+		string(wallet.ActivityStatusRefunded), string(wallet.ActivityStatusPending), string(wallet.ActivityStatusFailed))
+	schemas.Enum(wallet.ActivityPushMode(""), string(wallet.ActivityPushInstant), string(wallet.ActivityPushDigest),
+		string(wallet.ActivityPushOff))
+	schemas.Enum(stand.StandCategory(""), string(stand.StandCategoryBar), string(stand.StandCategoryFood),
+		string(stand.StandCategoryMerchandise), string(stand.StandCategoryTickets), string(stand.StandCategoryTopUp),
+		string(stand.StandCategoryOther))
+	schemas.Enum(stand.StandStatus(""), string(stand.StandStatusActive), string(stand.StandStatusInactive),
+		string(stand.StandStatusClosed))
+	schemas.Enum(stand.StaffRole(""), string(stand.StaffRoleManager), string(stand.StaffRoleCashier),
+		string(stand.StaffRoleAssistant))
+	schemas.Enum(product.ProductCategory(""), string(product.ProductCategoryBeer), string(product.ProductCategoryCocktail),
+		string(product.ProductCategorySoft), string(product.ProductCategoryFood), string(product.ProductCategorySnack),
+		string(product.ProductCategoryMerch), string(product.ProductCategoryOther))
+	schemas.Enum(product.ProductStatus(""), string(product.ProductStatusActive), string(product.ProductStatusInactive),
+		string(product.ProductStatusOutOfStock), string(product.ProductStatusRecalled))
+	allergens := make([]string, len(product.Allergens))
+	for i, a := range product.Allergens {
+		allergens[i] = string(a)
+	}
+	schemas.Enum(product.Allergen(""), allergens...)
+	dietaryTags := make([]string, len(product.DietaryTags))
+	for i, t := range product.DietaryTags {
+		dietaryTags[i] = string(t)
+	}
+	schemas.Enum(product.DietaryTag(""), dietaryTags...)
+	schemas.Enum(product.NutritionBasis(""), string(product.NutritionPer100g), string(product.NutritionPer100ml))
+
+	errorSchema := schemas.Of(response.ErrorResponse{})
+	meta := schemas.Of(response.Meta{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Festivals public API",
+			Description: "API of the attendee apps and the POS devices. Generated by cmd/openapi from the handler types, do not edit.",
+			Version:     Version,
+		},
+		Servers:  []openapi.Server{{URL: BasePath}},
+		Security: []map[string][]string{{"BearerAuth": {}}},
+		Paths:    make(map[string]openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"BearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	tags := make(map[string]bool)
+	for _, op := range Operations() {
+		operation := &openapi.Operation{
+			OperationID: op.ID,
+			Summary:     op.Summary,
+			Tags:        []string{op.Tag},
+			Parameters:  append(pathParameters(op.Path), op.Query...),
+			Responses:   make(map[string]*openapi.Response),
+		}
+		tags[op.Tag] = true
+
+		if op.Request != nil {
+			operation.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  jsonContent(schemas.Of(op.Request)),
+			}
+		}
+
+		success := &openapi.Response{Description: http.StatusText(op.Status)}
+		if op.Data != nil {
+			envelope := &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"data": schemas.Of(op.Data)},
+				Required:   []string{"data"},
+			}
+			if op.List {
+				envelope.Properties["meta"] = meta
+			}
+			success.Content = jsonContent(envelope)
+		}
+		operation.Responses[statusKey(op.Status)] = success
+
+		for _, status := range append(op.Errors, http.StatusInternalServerError) {
+			operation.Responses[statusKey(status)] = &openapi.Response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(errorSchema),
+			}
+		}
+
+		if doc.Paths[op.Path] == nil {
+			doc.Paths[op.Path] = make(openapi.PathItem)
+		}
+		doc.Paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, openapi.Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components.Schemas = schemas.Components()
+
+	return doc
+}
+
+// pathParameters declares the parameters of a path template, which are all IDs
+func pathParameters(path string) []openapi.Parameter {
+	var params []openapi.Parameter
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, openapi.Parameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string", Format: "uuid"},
+			})
+		}
+	}
+	return params
+}
+
+func query(name, typ, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: typ}}
+}
+
+func required(param openapi.Parameter) openapi.Parameter {
+	param.Required = true
+	return param
+}
+
+func jsonContent(schema *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{"application/json": {Schema: schema}}
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,152 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/festival"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/pkg/openapi"
+)
+
+// router mounts the handlers of the public API the way cmd/api does. The services
+// are nil: the tests only exercise the requests rejected before reaching them.
+func router() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	protected := r.Group(BasePath)
+	protected.Use(func(c *gin.Context) {
+		c.Set("user_id", uuid.New().String())
+		c.Next()
+	})
+
+	festival.NewHandler(nil).RegisterRoutes(protected)
+	walletHandler := wallet.NewHandler(nil)
+	walletHandler.RegisterRoutes(protected)
+
+	festivalScoped := protected.Group("/festivals/:id")
+	stand.NewHandler(nil).RegisterRoutes(festivalScoped)
+	product.NewHandler(nil).RegisterRoutes(festivalScoped)
+	walletHandler.RegisterFestivalRoutes(festivalScoped)
+
+	return r
+}
+
+var (
+	ginParam     = regexp.MustCompile(`:[^/]+`)
+	openapiParam = regexp.MustCompile(`\{[^/}]+\}`)
+)
+
+func TestPublicAPI_IsValid(t *testing.T) {
+	require.NoError(t, PublicAPI().Validate())
+}
+
+func TestPublicAPI_OperationsAreRouted(t *testing.T) {
+	routes := make(map[string]bool)
+	for _, route := range router().Routes() {
+		routes[route.Method+" "+ginParam.ReplaceAllString(route.Path, "{}")] = true
+	}
+
+	for _, op := range Operations() {
+		path := BasePath + openapiParam.ReplaceAllString(op.Path, "{}")
+		assert.True(t, routes[op.Method+" "+path], "%s %s is not routed", op.Method, op.Path)
+	}
+}
+
+func TestPublicAPI_RejectedRequestsMatchSpec(t *testing.T) {
+	doc := PublicAPI()
+	r := router()
+
+	// The stands of the current user are not scoped by the festival of their path
+	unchecked := map[string]bool{"listMyStands": true}
+
+	for _, op := range Operations() {
+		// Requests with malformed IDs and empty bodies are rejected before the services
+		if unchecked[op.ID] || (!strings.Contains(op.Path, "{") && op.Request == nil) {
+			continue
+		}
+		path := openapiParam.ReplaceAllString(op.Path, "not-a-uuid")
+
+		t.Run(op.ID, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(op.Method, BasePath+path, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.NoError(t, doc.ValidateResponse(op.Method, path, w.Code, w.Body.Bytes()))
+		})
+	}
+}
+
+func TestPublicAPI_RequestExamplesAreAccepted(t *testing.T) {
+	doc := PublicAPI()
+
+	for _, op := range Operations() {
+		if op.Request == nil {
+			continue
+		}
+
+		t.Run(op.ID, func(t *testing.T) {
+			operation, _ := doc.Operation(op.Method, op.Path)
+			require.NotNil(t, operation)
+			example, err := json.Marshal(doc.Example(operation.RequestBody.Content["application/json"].Schema))
+			require.NoError(t, err)
+
+			// The handler binds the example the spec generates for its request
+			req := reflect.New(reflect.TypeOf(op.Request)).Interface()
+			assert.NoError(t, binding.JSON.BindBody(example, req), string(example))
+		})
+	}
+}
+
+func TestPublicAPI_SpecFileIsUpToDate(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(file), "..", "..", "..", "docs", "api", "public-openapi.yaml")
+
+	committed, err := os.ReadFile(path)
+	require.NoError(t, err)
+	generated, err := PublicAPI().YAML()
+	require.NoError(t, err)
+
+	assert.True(t, bytes.Equal(committed, generated), "docs/api/public-openapi.yaml is stale, run make openapi")
+}
+
+func TestPublicAPI_MockServesSpec(t *testing.T) {
+	doc := PublicAPI()
+	mock := openapi.NewMock(doc, BasePath)
+
+	for _, op := range Operations() {
+		path := openapiParam.ReplaceAllString(op.Path, openapi.ExampleUUID)
+		var body []byte
+		if op.Request != nil {
+			operation, _ := doc.Operation(op.Method, op.Path)
+			body, _ = json.Marshal(doc.Example(operation.RequestBody.Content["application/json"].Schema))
+		}
+
+		w := httptest.NewRecorder()
+		mock.ServeHTTP(w, httptest.NewRequest(op.Method, BasePath+path, bytes.NewReader(body)))
+
+		require.Equal(t, op.Status, w.Code, "%s %s: %s", op.Method, op.Path, w.Body.String())
+		assert.NoError(t, doc.ValidateResponse(op.Method, path, w.Code, w.Body.Bytes()))
+	}
+}
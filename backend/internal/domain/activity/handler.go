@@ -0,0 +1,73 @@
+package activity
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers festival-scoped activity feed routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/activity", h.List)
+}
+
+// List lists the activity feed of the festival
+// @Summary List festival activity
+// @Description Get the organizer dashboard activity feed, latest first: order spikes, refunds, product edits, staff logins and alerts. Repeated activity is compacted into one entry with a count. New and updated entries are pushed as "activity" messages on the dashboard WebSocket channel.
+// @Tags activity
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param category query []string false "Categories, repeated or comma-separated" collectionFormat(csv) Enums(orders, refunds, products, staff, alerts)
+// @Param since query string false "Only activity since this time (RFC 3339)"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Success 200 {object} response.Response{data=[]Activity} "Activity"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/activity [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	activities, total, err := h.service.List(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, activities, &response.Meta{
+		Total:   int(total),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidCategory):
+		response.BadRequest(c, "INVALID_CATEGORY", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
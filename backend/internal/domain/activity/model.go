@@ -0,0 +1,92 @@
+package activity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CompactWindow is how long repeated activity of the same kind is merged into one entry
+	CompactWindow = 10 * time.Minute
+
+	// SpikeBaselineMinutes is the number of minutes the order rate is compared against
+	SpikeBaselineMinutes = 15
+	// SpikeMinOrders is the minimum number of orders in a minute to report a spike
+	SpikeMinOrders = 20
+	// SpikeFactor is how many times the baseline rate the orders of a minute must reach
+	SpikeFactor = 3
+	// SpikeCooldown is the minimum time between two spikes reported for a festival
+	SpikeCooldown = 15 * time.Minute
+)
+
+// Category groups activity for filtering
+type Category string
+
+const (
+	CategoryOrders   Category = "orders"
+	CategoryRefunds  Category = "refunds"
+	CategoryProducts Category = "products"
+	CategoryStaff    Category = "staff"
+	CategoryAlerts   Category = "alerts"
+)
+
+// IsValid checks if the category is known
+func (c Category) IsValid() bool {
+	switch c {
+	case CategoryOrders, CategoryRefunds, CategoryProducts, CategoryStaff, CategoryAlerts:
+		return true
+	}
+	return false
+}
+
+// Activity types
+const (
+	TypeOrderSpike     = "order_spike"
+	TypeRefund         = "refund"
+	TypeProductCreated = "product_created"
+	TypeProductUpdated = "product_updated"
+	TypeProductDeleted = "product_deleted"
+	TypeStaffLogin     = "staff_login"
+	TypeAlert          = "alert"
+)
+
+// Severity levels, matching the types of realtime alerts
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Activity is an entry of the organizer dashboard activity feed. Repeated activity of
+// the same kind within CompactWindow is merged into one entry, counting occurrences.
+type Activity struct {
+	ID           uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID   uuid.UUID              `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Category     Category               `json:"category" gorm:"not null"`
+	Type         string                 `json:"type" gorm:"not null"`
+	Severity     string                 `json:"severity" gorm:"not null;default:'info'"`
+	Title        string                 `json:"title" gorm:"not null"`
+	Message      string                 `json:"message,omitempty"`
+	ActorID      *uuid.UUID             `json:"actorId,omitempty" gorm:"type:uuid"`
+	ResourceType string                 `json:"resourceType,omitempty"`
+	ResourceID   string                 `json:"resourceId,omitempty"`
+	GroupKey     string                 `json:"-"`
+	Count        int                    `json:"count" gorm:"not null;default:1"`
+	Amount       int64                  `json:"amount,omitempty"` // Total in cents, for refunds
+	Metadata     map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+	FirstAt      time.Time              `json:"firstAt"`
+	OccurredAt   time.Time              `json:"occurredAt"` // Latest occurrence
+}
+
+func (Activity) TableName() string {
+	return "festival_activities"
+}
+
+// ListQuery filters and paginates the activity feed
+type ListQuery struct {
+	Categories []Category `form:"category"` // Repeat or comma-separate to select several
+	Since      *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int        `form:"page,default=1" binding:"min=1"`
+	PerPage    int        `form:"per_page,default=50" binding:"min=1,max=200"`
+}
@@ -0,0 +1,105 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, activity *Activity) error
+	FindGroup(ctx context.Context, festivalID uuid.UUID, groupKey string, since time.Time) (*Activity, error)
+	Merge(ctx context.Context, activity *Activity) error
+	List(ctx context.Context, festivalID uuid.UUID, query ListQuery) ([]Activity, int64, error)
+	FestivalOfStand(ctx context.Context, standID uuid.UUID) (uuid.UUID, string, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, activity *Activity) error {
+	if err := r.db.WithContext(ctx).Create(activity).Error; err != nil {
+		return fmt.Errorf("failed to create activity: %w", err)
+	}
+	return nil
+}
+
+// FindGroup returns the latest entry of a group started since the given time
+func (r *repository) FindGroup(ctx context.Context, festivalID uuid.UUID, groupKey string, since time.Time) (*Activity, error) {
+	var activity Activity
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND group_key = ? AND first_at >= ?", festivalID, groupKey, since).
+		Order("occurred_at DESC").
+		First(&activity).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find activity: %w", err)
+	}
+	return &activity, nil
+}
+
+// Merge saves the occurrence count, amount and latest occurrence of a merged entry
+func (r *repository) Merge(ctx context.Context, activity *Activity) error {
+	err := r.db.WithContext(ctx).Model(&Activity{}).
+		Where("id = ?", activity.ID).
+		Updates(map[string]interface{}{
+			"count":       activity.Count,
+			"amount":      activity.Amount,
+			"message":     activity.Message,
+			"occurred_at": activity.OccurredAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to merge activity: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, query ListQuery) ([]Activity, int64, error) {
+	var activities []Activity
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&Activity{}).Where("festival_id = ?", festivalID)
+	if len(query.Categories) > 0 {
+		q = q.Where("category IN ?", query.Categories)
+	}
+	if query.Since != nil {
+		q = q.Where("occurred_at >= ?", *query.Since)
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	if err := q.Offset(offset).Limit(query.PerPage).Order("occurred_at DESC").Find(&activities).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list activity: %w", err)
+	}
+
+	return activities, total, nil
+}
+
+// FestivalOfStand returns the festival and name of a stand
+func (r *repository) FestivalOfStand(ctx context.Context, standID uuid.UUID) (uuid.UUID, string, error) {
+	var stand struct {
+		FestivalID uuid.UUID
+		Name       string
+	}
+	err := r.db.WithContext(ctx).Table("stands").
+		Select("festival_id, name").
+		Where("id = ?", standID).
+		Take(&stand).Error
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to get stand festival: %w", err)
+	}
+	return stand.FestivalID, stand.Name, nil
+}
@@ -0,0 +1,49 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) Create(ctx context.Context, activity *Activity) error {
+	args := m.Called(ctx, activity)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindGroup(ctx context.Context, festivalID uuid.UUID, groupKey string, since time.Time) (*Activity, error) {
+	args := m.Called(ctx, festivalID, groupKey, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Activity), args.Error(1)
+}
+
+func (m *MockRepository) Merge(ctx context.Context, activity *Activity) error {
+	args := m.Called(ctx, activity)
+	return args.Error(0)
+}
+
+func (m *MockRepository) List(ctx context.Context, festivalID uuid.UUID, query ListQuery) ([]Activity, int64, error) {
+	args := m.Called(ctx, festivalID, query)
+	return args.Get(0).([]Activity), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) FestivalOfStand(ctx context.Context, standID uuid.UUID) (uuid.UUID, string, error) {
+	args := m.Called(ctx, standID)
+	return args.Get(0).(uuid.UUID), args.String(1), args.Error(2)
+}
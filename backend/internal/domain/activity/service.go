@@ -0,0 +1,296 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidCategory is returned when filtering on an unknown category
+var ErrInvalidCategory = errors.New("unknown activity category")
+
+// Broadcaster pushes alerts and feed entries to the organizer dashboards, satisfied by
+// realtime.Service
+type Broadcaster interface {
+	BroadcastAlert(festivalID string, alert *realtime.Alert)
+	BroadcastActivity(ctx context.Context, festivalID string, activity interface{})
+}
+
+// Service records the activity of festivals into a compact feed and tails it live on
+// the dashboard channel
+type Service struct {
+	repo        Repository
+	redis       *redis.Client
+	broadcaster Broadcaster
+	now         func() time.Time
+}
+
+// NewService creates an activity feed service. Order spikes are only detected when
+// a Redis client is given.
+func NewService(repo Repository, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:  repo,
+		redis: redisClient,
+		now:   time.Now,
+	}
+}
+
+// SetBroadcaster enables the live tail and forwards the alerts recorded to the dashboards
+func (s *Service) SetBroadcaster(broadcaster Broadcaster) {
+	s.broadcaster = broadcaster
+}
+
+// List lists the activity of a festival, latest first
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, query ListQuery) ([]Activity, int64, error) {
+	var categories []Category
+	for _, c := range query.Categories {
+		for _, name := range strings.Split(string(c), ",") {
+			category := Category(strings.TrimSpace(name))
+			if category == "" {
+				continue
+			}
+			if !category.IsValid() {
+				return nil, 0, fmt.Errorf("%w: %s", ErrInvalidCategory, category)
+			}
+			categories = append(categories, category)
+		}
+	}
+	query.Categories = categories
+
+	return s.repo.List(ctx, festivalID, query)
+}
+
+// ObserveOrder counts an order towards the order rate of the festival and records a
+// spike when the rate of the current minute jumps above the recent baseline
+func (s *Service) ObserveOrder(ctx context.Context, festivalID uuid.UUID, at time.Time) {
+	if s.redis == nil {
+		return
+	}
+
+	minute := at.Unix() / 60
+	key := orderBucketKey(festivalID, minute)
+	pipe := s.redis.Pipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, time.Duration(SpikeBaselineMinutes+2)*time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Debug().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to count order for activity feed")
+		return
+	}
+	count := incr.Val()
+	if count < SpikeMinOrders {
+		return
+	}
+
+	keys := make([]string, SpikeBaselineMinutes)
+	for i := range keys {
+		keys[i] = orderBucketKey(festivalID, minute-int64(i+1))
+	}
+	values, err := s.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return
+	}
+	previous := make([]int64, len(values))
+	for i, v := range values {
+		if str, ok := v.(string); ok {
+			previous[i], _ = strconv.ParseInt(str, 10, 64)
+		}
+	}
+
+	baseline := orderBaseline(previous)
+	if !isSpike(count, baseline) {
+		return
+	}
+
+	// One spike per cooldown, whichever instance sees it first
+	acquired, err := s.redis.SetNX(ctx, "activity:orders:spike:"+festivalID.String(), minute, SpikeCooldown).Result()
+	if err != nil || !acquired {
+		return
+	}
+
+	s.record(ctx, &Activity{
+		FestivalID: festivalID,
+		Category:   CategoryOrders,
+		Type:       TypeOrderSpike,
+		Severity:   SeverityWarning,
+		Title:      "Order spike",
+		Message:    fmt.Sprintf("%d orders in the last minute, against %.1f per minute over the previous %d minutes", count, baseline, SpikeBaselineMinutes),
+		Metadata: map[string]interface{}{
+			"orders":   count,
+			"baseline": baseline,
+		},
+	})
+}
+
+// RecordRefund records a refund changing status. Refunds reaching the same status are
+// compacted, summing their amounts.
+func (s *Service) RecordRefund(ctx context.Context, festivalID, refundID uuid.UUID, status string, amount int64, actorID *uuid.UUID) {
+	status = strings.ToLower(status)
+	s.record(ctx, &Activity{
+		FestivalID:   festivalID,
+		Category:     CategoryRefunds,
+		Type:         TypeRefund,
+		Title:        "Refund " + status,
+		ActorID:      actorID,
+		ResourceType: "refund",
+		ResourceID:   refundID.String(),
+		GroupKey:     "refund:" + status,
+		Amount:       amount,
+		Metadata:     map[string]interface{}{"status": status},
+	})
+}
+
+// RecordProductChange records a product of a stand being created, updated or deleted.
+// Successive edits of a product are compacted.
+func (s *Service) RecordProductChange(ctx context.Context, standID, productID uuid.UUID, name, change string) {
+	festivalID, standName, err := s.repo.FestivalOfStand(ctx, standID)
+	if err != nil {
+		log.Warn().Err(err).Str("stand_id", standID.String()).Msg("Product change not recorded in activity feed")
+		return
+	}
+
+	s.record(ctx, &Activity{
+		FestivalID:   festivalID,
+		Category:     CategoryProducts,
+		Type:         "product_" + change,
+		Title:        fmt.Sprintf("Product %s: %s", change, name),
+		Message:      standName,
+		ResourceType: "product",
+		ResourceID:   productID.String(),
+		GroupKey:     fmt.Sprintf("product:%s:%s", productID, change),
+		Metadata:     map[string]interface{}{"standId": standID.String()},
+	})
+}
+
+// RecordStaffLogin records a staff member signing in at a stand
+func (s *Service) RecordStaffLogin(ctx context.Context, standID, userID uuid.UUID) {
+	festivalID, standName, err := s.repo.FestivalOfStand(ctx, standID)
+	if err != nil {
+		log.Warn().Err(err).Str("stand_id", standID.String()).Msg("Staff login not recorded in activity feed")
+		return
+	}
+
+	s.record(ctx, &Activity{
+		FestivalID:   festivalID,
+		Category:     CategoryStaff,
+		Type:         TypeStaffLogin,
+		Title:        "Staff login at " + standName,
+		ActorID:      &userID,
+		ResourceType: "stand",
+		ResourceID:   standID.String(),
+		GroupKey:     fmt.Sprintf("staff_login:%s:%s", standID, userID),
+	})
+}
+
+// BroadcastAlert records an organizer alert and forwards it to the dashboards, so that
+// the service can stand in for the realtime service as an alerter
+func (s *Service) BroadcastAlert(festivalID string, alert *realtime.Alert) {
+	if s.broadcaster != nil {
+		s.broadcaster.BroadcastAlert(festivalID, alert)
+	}
+
+	id, err := uuid.Parse(festivalID)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	severity := alert.Type
+	if severity == "" {
+		severity = SeverityInfo
+	}
+	s.record(ctx, &Activity{
+		FestivalID: id,
+		Category:   CategoryAlerts,
+		Type:       TypeAlert,
+		Severity:   severity,
+		Title:      alert.Title,
+		Message:    alert.Message,
+		GroupKey:   "alert:" + alert.Title,
+		Metadata:   map[string]interface{}{"actionUrl": alert.ActionURL},
+	})
+}
+
+// record saves an activity, merging it into the entry of its group started within
+// CompactWindow, and tails it to the dashboards
+func (s *Service) record(ctx context.Context, activity *Activity) {
+	now := s.now()
+
+	if activity.GroupKey != "" {
+		existing, err := s.repo.FindGroup(ctx, activity.FestivalID, activity.GroupKey, now.Add(-CompactWindow))
+		if err != nil {
+			log.Error().Err(err).Str("type", activity.Type).Msg("Failed to record activity")
+			return
+		}
+		if existing != nil {
+			existing.Count++
+			existing.Amount += activity.Amount
+			existing.Message = activity.Message
+			existing.OccurredAt = now
+			if err := s.repo.Merge(ctx, existing); err != nil {
+				log.Error().Err(err).Str("type", activity.Type).Msg("Failed to record activity")
+				return
+			}
+			s.tail(ctx, existing)
+			return
+		}
+	}
+
+	activity.ID = uuid.New()
+	activity.Count = 1
+	activity.FirstAt = now
+	activity.OccurredAt = now
+	if activity.Severity == "" {
+		activity.Severity = SeverityInfo
+	}
+	if err := s.repo.Create(ctx, activity); err != nil {
+		log.Error().Err(err).Str("type", activity.Type).Msg("Failed to record activity")
+		return
+	}
+	s.tail(ctx, activity)
+}
+
+// tail pushes a new or merged entry to the dashboards, which replace entries by ID
+func (s *Service) tail(ctx context.Context, activity *Activity) {
+	if s.broadcaster != nil {
+		s.broadcaster.BroadcastActivity(ctx, activity.FestivalID.String(), activity)
+	}
+}
+
+func orderBucketKey(festivalID uuid.UUID, minute int64) string {
+	return fmt.Sprintf("activity:orders:%s:%d", festivalID, minute)
+}
+
+// orderBaseline is the average orders per minute of the previous minutes
+func orderBaseline(previous []int64) float64 {
+	if len(previous) == 0 {
+		return 0
+	}
+	var total int64
+	for _, count := range previous {
+		total += count
+	}
+	return float64(total) / float64(len(previous))
+}
+
+// isSpike reports whether the orders of a minute are a spike against the baseline. A
+// quiet baseline counts as one order per minute; SpikeMinOrders keeps small festivals
+// from reporting noise.
+func isSpike(count int64, baseline float64) bool {
+	if count < SpikeMinOrders {
+		return false
+	}
+	if baseline < 1 {
+		baseline = 1
+	}
+	return float64(count) >= SpikeFactor*baseline
+}
@@ -0,0 +1,131 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBroadcaster struct {
+	alerts     []*realtime.Alert
+	activities []Activity
+}
+
+func (b *fakeBroadcaster) BroadcastAlert(festivalID string, alert *realtime.Alert) {
+	b.alerts = append(b.alerts, alert)
+}
+
+func (b *fakeBroadcaster) BroadcastActivity(ctx context.Context, festivalID string, activity interface{}) {
+	b.activities = append(b.activities, *activity.(*Activity))
+}
+
+func newTestService(repo Repository) (*Service, *fakeBroadcaster, *time.Time) {
+	broadcaster := &fakeBroadcaster{}
+	now := time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+
+	service := NewService(repo, nil)
+	service.SetBroadcaster(broadcaster)
+	service.now = func() time.Time { return now }
+	return service, broadcaster, &now
+}
+
+// TestRecordRefund_Compacts tests that refunds reaching a status within the window are
+// merged into one entry summing their amounts
+func TestRecordRefund_Compacts(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, broadcaster, now := newTestService(mockRepo)
+	ctx := context.Background()
+	festivalID := uuid.New()
+
+	var created []*Activity
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*activity.Activity")).
+		Run(func(args mock.Arguments) { created = append(created, args.Get(1).(*Activity)) }).
+		Return(nil)
+
+	mockRepo.On("FindGroup", ctx, festivalID, "refund:completed", now.Add(-CompactWindow)).Return(nil, nil).Once()
+	service.RecordRefund(ctx, festivalID, uuid.New(), "COMPLETED", 1500, nil)
+	require.Len(t, created, 1)
+	first := *created[0]
+	assert.Equal(t, "Refund completed", first.Title)
+	assert.Equal(t, 1, first.Count)
+	assert.Equal(t, *now, first.FirstAt)
+
+	*now = now.Add(4 * time.Minute)
+	mockRepo.On("FindGroup", ctx, festivalID, "refund:completed", now.Add(-CompactWindow)).Return(&first, nil).Once()
+	mockRepo.On("Merge", ctx, mock.MatchedBy(func(a *Activity) bool {
+		return a.ID == first.ID && a.Count == 2 && a.Amount == 4000 && a.OccurredAt.Equal(*now)
+	})).Return(nil).Once()
+	service.RecordRefund(ctx, festivalID, uuid.New(), "COMPLETED", 2500, nil)
+
+	mockRepo.On("FindGroup", ctx, festivalID, "refund:rejected", now.Add(-CompactWindow)).Return(nil, nil).Once()
+	service.RecordRefund(ctx, festivalID, uuid.New(), "REJECTED", 1000, nil)
+	assert.Len(t, created, 2)
+
+	require.Len(t, broadcaster.activities, 3, "merged entries are tailed again")
+	assert.Equal(t, first.ID, broadcaster.activities[1].ID)
+
+	// The repository only looks for groups started within the window
+	*now = now.Add(CompactWindow)
+	mockRepo.On("FindGroup", ctx, festivalID, "refund:completed", now.Add(-CompactWindow)).Return(nil, nil).Once()
+	service.RecordRefund(ctx, festivalID, uuid.New(), "COMPLETED", 500, nil)
+	assert.Len(t, created, 3, "a new entry starts once the window is over")
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestBroadcastAlert tests that alerts are forwarded to the dashboards and recorded
+func TestBroadcastAlert(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, broadcaster, _ := newTestService(mockRepo)
+	festivalID := uuid.New()
+
+	mockRepo.On("FindGroup", mock.Anything, festivalID, "alert:Long wait at Bar Central", mock.Anything).Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(a *Activity) bool {
+		return a.Category == CategoryAlerts && a.Severity == SeverityError && a.FestivalID == festivalID
+	})).Return(nil).Once()
+
+	service.BroadcastAlert(festivalID.String(), &realtime.Alert{
+		Type:    "error",
+		Title:   "Long wait at Bar Central",
+		Message: "Estimated wait is 25 minutes with 40 open orders",
+	})
+
+	require.Len(t, broadcaster.alerts, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestList_Categories tests that categories can be comma-separated and are validated
+func TestList_Categories(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, _ := newTestService(mockRepo)
+
+	mockRepo.On("List", mock.Anything, mock.Anything, mock.MatchedBy(func(q ListQuery) bool {
+		return assert.ObjectsAreEqual([]Category{CategoryOrders, CategoryRefunds, CategoryAlerts}, q.Categories)
+	})).Return([]Activity{}, int64(0), nil).Once()
+
+	_, _, err := service.List(context.Background(), uuid.New(), ListQuery{
+		Categories: []Category{"orders,refunds", "alerts"},
+		Page:       1,
+		PerPage:    50,
+	})
+	require.NoError(t, err)
+
+	_, _, err = service.List(context.Background(), uuid.New(), ListQuery{Categories: []Category{"sales"}})
+	assert.ErrorIs(t, err, ErrInvalidCategory)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestIsSpike tests the order spike detection against the baseline rate
+func TestIsSpike(t *testing.T) {
+	assert.False(t, isSpike(SpikeMinOrders-1, 0), "below the minimum")
+	assert.True(t, isSpike(SpikeMinOrders, 0), "from a quiet baseline")
+	assert.False(t, isSpike(40, orderBaseline([]int64{15, 20, 10})))
+	assert.True(t, isSpike(45, orderBaseline([]int64{15, 20, 10})))
+}
@@ -100,7 +100,7 @@ func (h *Handler) CreateSuperAdmin(c *gin.Context) {
 
 	var req CreateSuperAdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -188,7 +188,7 @@ func (h *Handler) UpdateSuperAdmin(c *gin.Context) {
 
 	var req UpdateSuperAdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -350,7 +350,7 @@ func (h *Handler) UpdateSetting(c *gin.Context) {
 
 	var req UpdateSettingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
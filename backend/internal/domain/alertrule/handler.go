@@ -0,0 +1,266 @@
+package alertrule
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin alert rule routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	rules := r.Group("/alert-rules")
+	{
+		rules.GET("", h.List)
+		rules.POST("", h.Create)
+		rules.GET("/channels", h.Channels)
+		rules.POST("/dry-run", h.DryRun)
+		rules.GET("/:id", h.GetByID)
+		rules.PATCH("/:id", h.Update)
+		rules.DELETE("/:id", h.Delete)
+		rules.POST("/:id/dry-run", h.DryRunRule)
+	}
+}
+
+// List lists the security alert rules
+// @Summary List alert rules
+// @Description Get the rules deciding when security events alert the security team
+// @Tags alert-rules
+// @Produce json
+// @Success 200 {object} response.Response{data=[]Rule} "Alert rules"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/alert-rules [get]
+func (h *Handler) List(c *gin.Context) {
+	rules, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rules)
+}
+
+// Create defines a security alert rule
+// @Summary Create alert rule
+// @Description Alert the selected channels when a number of security events of the given types occur within a window for one IP address, user or festival. The rule applies on every instance without a restart; once rules exist they replace the static thresholds.
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body CreateRuleRequest true "Alert rule"
+// @Success 201 {object} response.Response{data=Rule} "Alert rule created"
+// @Failure 400 {object} response.ErrorResponse "Invalid rule"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/alert-rules [post]
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	rule, err := h.service.Create(c.Request.Context(), adminID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, rule)
+}
+
+// Channels lists the alert channels
+// @Summary List alert channels
+// @Description Get the alert channels configured on the server that rules can notify
+// @Tags alert-rules
+// @Produce json
+// @Success 200 {object} response.Response{data=[]string} "Alert channels"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/alert-rules/channels [get]
+func (h *Handler) Channels(c *gin.Context) {
+	response.OK(c, h.service.Channels())
+}
+
+// DryRun shows how often a proposed rule would have fired
+// @Summary Dry-run alert rule
+// @Description Replay the security events of the last days through a proposed rule and report how often it would have fired, without saving it
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body CreateRuleRequest true "Proposed alert rule"
+// @Param days query int false "Period in days (max 7)" default(7)
+// @Success 200 {object} response.Response{data=DryRunResult} "Dry run result"
+// @Failure 400 {object} response.ErrorResponse "Invalid rule"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/alert-rules/dry-run [post]
+func (h *Handler) DryRun(c *gin.Context) {
+	var req CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.DryRun(c.Request.Context(), req, dryRunDays(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// GetByID gets a security alert rule
+// @Summary Get alert rule
+// @Description Get a security alert rule
+// @Tags alert-rules
+// @Produce json
+// @Param id path string true "Rule ID" format(uuid)
+// @Success 200 {object} response.Response{data=Rule} "Alert rule"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Rule not found"
+// @Security BearerAuth
+// @Router /admin/alert-rules/{id} [get]
+func (h *Handler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid alert rule ID", nil)
+		return
+	}
+
+	rule, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rule)
+}
+
+// Update changes a security alert rule
+// @Summary Update alert rule
+// @Description Change the events, threshold, window or channels of a rule, or disable it. The change applies on every instance without a restart.
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID" format(uuid)
+// @Param request body UpdateRuleRequest true "Changes"
+// @Success 200 {object} response.Response{data=Rule} "Alert rule updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid rule"
+// @Failure 404 {object} response.ErrorResponse "Rule not found"
+// @Security BearerAuth
+// @Router /admin/alert-rules/{id} [patch]
+func (h *Handler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid alert rule ID", nil)
+		return
+	}
+
+	var req UpdateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	rule, err := h.service.Update(c.Request.Context(), id, adminID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rule)
+}
+
+// Delete removes a security alert rule
+// @Summary Delete alert rule
+// @Description Remove a rule on every instance. Removing the last rule restores the static thresholds.
+// @Tags alert-rules
+// @Param id path string true "Rule ID" format(uuid)
+// @Success 204 "Alert rule deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Rule not found"
+// @Security BearerAuth
+// @Router /admin/alert-rules/{id} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid alert rule ID", nil)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, adminID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// DryRunRule shows how often an existing rule would have fired
+// @Summary Dry-run existing alert rule
+// @Description Replay the security events of the last days through a saved rule and report how often it would have fired
+// @Tags alert-rules
+// @Produce json
+// @Param id path string true "Rule ID" format(uuid)
+// @Param days query int false "Period in days (max 7)" default(7)
+// @Success 200 {object} response.Response{data=DryRunResult} "Dry run result"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Rule not found"
+// @Security BearerAuth
+// @Router /admin/alert-rules/{id}/dry-run [post]
+func (h *Handler) DryRunRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid alert rule ID", nil)
+		return
+	}
+
+	result, err := h.service.DryRunRule(c.Request.Context(), id, dryRunDays(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+func adminID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func dryRunDays(c *gin.Context) int {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(DefaultDryRunDays)))
+	return days
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound):
+		response.NotFound(c, "Alert rule not found")
+	case errors.Is(err, ErrInvalidEventType):
+		response.BadRequest(c, "INVALID_EVENT_TYPE", err.Error(), nil)
+	case errors.Is(err, ErrUnknownChannel):
+		response.BadRequest(c, "UNKNOWN_CHANNEL", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
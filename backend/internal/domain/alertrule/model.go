@@ -0,0 +1,130 @@
+package alertrule
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+const (
+	// ReloadChannel is the Redis pub/sub channel rule changes are broadcast on, so that
+	// the auditor of every API instance reloads them
+	ReloadChannel = "alertrules:reload"
+
+	// DefaultDryRunDays is the period a dry run replays
+	DefaultDryRunDays = 7
+	// MaxDryRunEvents caps the security events a dry run replays
+	MaxDryRunEvents = 100000
+	// maxDryRunSamples is the number of alerts listed in a dry run result
+	maxDryRunSamples = 20
+)
+
+// Alert rule errors
+var (
+	ErrRuleNotFound     = errors.New("alert rule not found")
+	ErrInvalidEventType = errors.New("unknown security event type")
+	ErrUnknownChannel   = errors.New("unknown alert channel")
+)
+
+// GroupBy is what a rule counts events per
+type GroupBy string
+
+const (
+	GroupByIP       GroupBy = "ip"
+	GroupByUser     GroupBy = "user"
+	GroupByFestival GroupBy = "festival"
+	GroupByNone     GroupBy = "none"
+)
+
+// Rule alerts the selected channels when Threshold events of the given types occur
+// within the window for one IP address, user or festival. Once rules exist they
+// replace the static thresholds of the security auditor.
+type Rule struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name          string     `json:"name" gorm:"not null"`
+	Description   string     `json:"description,omitempty"`
+	EventTypes    []string   `json:"eventTypes" gorm:"type:jsonb;serializer:json"`
+	Threshold     int        `json:"threshold" gorm:"not null"`
+	WindowSeconds int        `json:"windowSeconds" gorm:"not null"`
+	GroupBy       GroupBy    `json:"groupBy" gorm:"not null;default:'ip'"`
+	Channels      []string   `json:"channels" gorm:"type:jsonb;serializer:json"` // All channels when empty
+	Enabled       bool       `json:"enabled" gorm:"default:true"`
+	CreatedBy     *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	UpdatedBy     *uuid.UUID `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (Rule) TableName() string {
+	return "security_alert_rules"
+}
+
+// ToSecurityRule converts the rule to the form evaluated by the security auditor
+func (r *Rule) ToSecurityRule() security.AlertRule {
+	eventTypes := make([]security.SecurityEventType, len(r.EventTypes))
+	for i, t := range r.EventTypes {
+		eventTypes[i] = security.SecurityEventType(t)
+	}
+
+	return security.AlertRule{
+		ID:         r.ID.String(),
+		Name:       r.Name,
+		EventTypes: eventTypes,
+		Threshold:  r.Threshold,
+		Window:     time.Duration(r.WindowSeconds) * time.Second,
+		GroupBy:    security.AlertGroupBy(r.GroupBy),
+		Channels:   r.Channels,
+	}
+}
+
+// CreateRuleRequest represents an administrator defining an alert rule
+type CreateRuleRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Description   string   `json:"description" binding:"max=500"`
+	EventTypes    []string `json:"eventTypes" binding:"required,min=1,dive,required"`
+	Threshold     int      `json:"threshold" binding:"required,min=1"`
+	WindowSeconds int      `json:"windowSeconds" binding:"required,min=1,max=604800"`
+	GroupBy       GroupBy  `json:"groupBy" binding:"omitempty,oneof=ip user festival none"`
+	Channels      []string `json:"channels" binding:"dive,required"`
+	Enabled       *bool    `json:"enabled"`
+}
+
+// UpdateRuleRequest represents the changes to an alert rule
+type UpdateRuleRequest struct {
+	Name          *string  `json:"name" binding:"omitempty,max=100"`
+	Description   *string  `json:"description" binding:"omitempty,max=500"`
+	EventTypes    []string `json:"eventTypes" binding:"omitempty,min=1,dive,required"`
+	Threshold     *int     `json:"threshold" binding:"omitempty,min=1"`
+	WindowSeconds *int     `json:"windowSeconds" binding:"omitempty,min=1,max=604800"`
+	GroupBy       *GroupBy `json:"groupBy" binding:"omitempty,oneof=ip user festival none"`
+	Channels      []string `json:"channels" binding:"omitempty,dive,required"`
+	Enabled       *bool    `json:"enabled"`
+}
+
+// DryRunResult reports how often a rule would have fired over the last days
+type DryRunResult struct {
+	From           time.Time    `json:"from"`
+	To             time.Time    `json:"to"`
+	EventsReplayed int          `json:"eventsReplayed"`
+	MatchingEvents int          `json:"matchingEvents"`
+	Fires          int          `json:"fires"`
+	Groups         int          `json:"groups"` // Distinct IP addresses, users or festivals alerted on
+	ByDay          []DayFires   `json:"byDay"`
+	Samples        []FireSample `json:"samples"`   // Earliest alerts
+	Truncated      bool         `json:"truncated"` // More events than MaxDryRunEvents were recorded
+}
+
+// DayFires is the number of alerts a rule would have sent on one day
+type DayFires struct {
+	Date  string `json:"date"` // YYYY-MM-DD, UTC
+	Fires int    `json:"fires"`
+}
+
+// FireSample is an alert a rule would have sent
+type FireSample struct {
+	At        time.Time                  `json:"at"`
+	Group     string                     `json:"group"`
+	EventType security.SecurityEventType `json:"eventType"`
+}
@@ -0,0 +1,48 @@
+package alertrule
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) Create(ctx context.Context, rule *Rule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetByID(ctx context.Context, id uuid.UUID) (*Rule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Rule), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context) ([]Rule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Rule), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, rule *Rule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,364 @@
+package alertrule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Auditor evaluates the rules and replays the recorded security events, satisfied by
+// security.SecurityAuditor
+type Auditor interface {
+	SetAlertRules(rules []security.AlertRule)
+	AlertChannels() []string
+	QueryEvents(ctx context.Context, filter security.EventFilter) ([]security.SecurityEvent, error)
+}
+
+// AuditLogger records rule changes, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service manages the alert rules and hot-loads them into the security auditor
+type Service struct {
+	repo    Repository
+	auditor Auditor
+	audit   AuditLogger
+	redis   *redis.Client
+	now     func() time.Time
+}
+
+// NewService creates an alert rule service loading the rules into auditor
+func NewService(repo Repository, auditor Auditor) *Service {
+	return &Service{
+		repo:    repo,
+		auditor: auditor,
+		now:     time.Now,
+	}
+}
+
+// SetAuditLogger records rule changes in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// SetBroadcaster tells the other instances to reload the rules through Redis
+func (s *Service) SetBroadcaster(client *redis.Client) {
+	s.redis = client
+}
+
+// Load loads the enabled rules into the auditor. The static thresholds stay in use
+// until a first rule is defined.
+func (s *Service) Load(ctx context.Context) error {
+	rules, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		s.auditor.SetAlertRules(nil)
+		return nil
+	}
+
+	enabled := make([]security.AlertRule, 0, len(rules))
+	for i := range rules {
+		if rules[i].Enabled {
+			enabled = append(enabled, rules[i].ToSecurityRule())
+		}
+	}
+	s.auditor.SetAlertRules(enabled)
+
+	log.Info().Int("rules", len(rules)).Int("enabled", len(enabled)).Msg("Security alert rules loaded")
+	return nil
+}
+
+// Listen reloads the rules whenever another instance changes them, until ctx is done
+func (s *Service) Listen(ctx context.Context) error {
+	if s.redis == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	pubsub := s.redis.Subscribe(ctx, ReloadChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to alert rule channel: %w", err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := s.Load(ctx); err != nil {
+					log.Error().Err(err).Msg("Failed to reload security alert rules")
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// List lists the alert rules
+func (s *Service) List(ctx context.Context) ([]Rule, error) {
+	return s.repo.List(ctx)
+}
+
+// Get returns an alert rule
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Rule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+// Channels returns the alert channels rules can notify
+func (s *Service) Channels() []string {
+	return s.auditor.AlertChannels()
+}
+
+// Create defines an alert rule and applies it on every instance
+func (s *Service) Create(ctx context.Context, adminID *uuid.UUID, req CreateRuleRequest) (*Rule, error) {
+	rule := &Rule{
+		ID:            uuid.New(),
+		Name:          req.Name,
+		Description:   req.Description,
+		EventTypes:    req.EventTypes,
+		Threshold:     req.Threshold,
+		WindowSeconds: req.WindowSeconds,
+		GroupBy:       req.GroupBy,
+		Channels:      req.Channels,
+		Enabled:       true,
+		CreatedBy:     adminID,
+		UpdatedBy:     adminID,
+		CreatedAt:     s.now(),
+		UpdatedAt:     s.now(),
+	}
+	if rule.GroupBy == "" {
+		rule.GroupBy = GroupByIP
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.changed(ctx, adminID, "create", rule)
+	return rule, nil
+}
+
+// Update changes an alert rule and applies it on every instance
+func (s *Service) Update(ctx context.Context, id uuid.UUID, adminID *uuid.UUID, req UpdateRuleRequest) (*Rule, error) {
+	rule, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		rule.EventTypes = req.EventTypes
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.WindowSeconds != nil {
+		rule.WindowSeconds = *req.WindowSeconds
+	}
+	if req.GroupBy != nil {
+		rule.GroupBy = *req.GroupBy
+	}
+	if req.Channels != nil {
+		rule.Channels = req.Channels
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+
+	rule.UpdatedBy = adminID
+	rule.UpdatedAt = s.now()
+	if err := s.repo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.changed(ctx, adminID, "update", rule)
+	return rule, nil
+}
+
+// Delete removes an alert rule on every instance
+func (s *Service) Delete(ctx context.Context, id uuid.UUID, adminID *uuid.UUID) error {
+	rule, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, rule.ID); err != nil {
+		return err
+	}
+
+	s.changed(ctx, adminID, "delete", rule)
+	return nil
+}
+
+// DryRun replays the security events of the last days through a proposed rule and
+// reports how often it would have fired
+func (s *Service) DryRun(ctx context.Context, req CreateRuleRequest, days int) (*DryRunResult, error) {
+	rule := &Rule{
+		ID:            uuid.New(),
+		Name:          req.Name,
+		EventTypes:    req.EventTypes,
+		Threshold:     req.Threshold,
+		WindowSeconds: req.WindowSeconds,
+		GroupBy:       req.GroupBy,
+		Channels:      req.Channels,
+	}
+	if rule.GroupBy == "" {
+		rule.GroupBy = GroupByIP
+	}
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+
+	return s.dryRun(ctx, rule, days)
+}
+
+// DryRunRule replays the security events of the last days through an existing rule
+func (s *Service) DryRunRule(ctx context.Context, id uuid.UUID, days int) (*DryRunResult, error) {
+	rule, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.dryRun(ctx, rule, days)
+}
+
+func (s *Service) dryRun(ctx context.Context, rule *Rule, days int) (*DryRunResult, error) {
+	if days < 1 || days > DefaultDryRunDays {
+		days = DefaultDryRunDays
+	}
+
+	to := s.now().UTC()
+	from := to.AddDate(0, 0, -days)
+	events, err := s.auditor.QueryEvents(ctx, security.EventFilter{
+		StartTime: from,
+		EndTime:   to,
+		Limit:     MaxDryRunEvents,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay security events: %w", err)
+	}
+
+	securityRule := rule.ToSecurityRule()
+	firings := security.SimulateAlertRule(securityRule, events)
+
+	result := &DryRunResult{
+		From:           from,
+		To:             to,
+		EventsReplayed: len(events),
+		Fires:          len(firings),
+		ByDay:          make([]DayFires, 0, days+1),
+		Samples:        make([]FireSample, 0, maxDryRunSamples),
+		Truncated:      len(events) >= MaxDryRunEvents,
+	}
+	for i := range events {
+		if securityRule.Matches(&events[i]) {
+			result.MatchingEvents++
+		}
+	}
+
+	groups := make(map[string]bool)
+	byDay := make(map[string]int)
+	for _, f := range firings {
+		groups[f.Group] = true
+		byDay[f.At.UTC().Format("2006-01-02")]++
+		if len(result.Samples) < maxDryRunSamples {
+			result.Samples = append(result.Samples, FireSample{At: f.At, Group: f.Group, EventType: f.Event.Type})
+		}
+	}
+	result.Groups = len(groups)
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		result.ByDay = append(result.ByDay, DayFires{Date: date, Fires: byDay[date]})
+	}
+
+	return result, nil
+}
+
+func (s *Service) validate(rule *Rule) error {
+	for _, t := range rule.EventTypes {
+		if !security.SecurityEventType(t).IsValid() {
+			return fmt.Errorf("%w: %s", ErrInvalidEventType, t)
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, channel := range s.auditor.AlertChannels() {
+		known[channel] = true
+	}
+	for _, channel := range rule.Channels {
+		if !known[channel] {
+			return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+		}
+	}
+	return nil
+}
+
+// changed reloads the rules here and on the other instances, and audits the change
+func (s *Service) changed(ctx context.Context, adminID *uuid.UUID, op string, rule *Rule) {
+	if err := s.Load(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to reload security alert rules")
+	}
+
+	if s.redis != nil {
+		if err := s.redis.Publish(ctx, ReloadChannel, rule.ID.String()).Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to broadcast security alert rule change")
+		}
+	}
+
+	if s.audit != nil {
+		s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     adminID,
+			Action:     audit.ActionSettingsUpdate,
+			Resource:   "security_alert_rule",
+			ResourceID: rule.ID.String(),
+			Metadata: map[string]interface{}{
+				"op":            op,
+				"name":          rule.Name,
+				"eventTypes":    rule.EventTypes,
+				"threshold":     rule.Threshold,
+				"windowSeconds": rule.WindowSeconds,
+				"groupBy":       rule.GroupBy,
+				"channels":      rule.Channels,
+				"enabled":       rule.Enabled,
+			},
+		})
+	}
+}
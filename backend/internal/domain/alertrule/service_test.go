@@ -0,0 +1,144 @@
+package alertrule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditor struct {
+	rules  []security.AlertRule
+	events []security.SecurityEvent
+}
+
+func (a *fakeAuditor) SetAlertRules(rules []security.AlertRule) {
+	a.rules = rules
+}
+
+func (a *fakeAuditor) AlertChannels() []string {
+	return []string{"slack", "webhook"}
+}
+
+func (a *fakeAuditor) QueryEvents(ctx context.Context, filter security.EventFilter) ([]security.SecurityEvent, error) {
+	return a.events, nil
+}
+
+var testNow = time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+
+func bruteForceRequest() CreateRuleRequest {
+	return CreateRuleRequest{
+		Name:          "Brute force",
+		EventTypes:    []string{"AUTH_FAILURE"},
+		Threshold:     3,
+		WindowSeconds: 300,
+		Channels:      []string{"slack"},
+	}
+}
+
+// TestCreate_HotLoadsRules tests that rules are applied to the auditor as they change
+func TestCreate_HotLoadsRules(t *testing.T) {
+	mockRepo := NewMockRepository()
+	auditor := &fakeAuditor{}
+	service := NewService(mockRepo, auditor)
+	service.now = func() time.Time { return testNow }
+
+	stored := Rule{
+		ID:            uuid.New(),
+		Name:          "Brute force",
+		EventTypes:    []string{"AUTH_FAILURE"},
+		Threshold:     3,
+		WindowSeconds: 300,
+		GroupBy:       GroupByIP,
+		Channels:      []string{"slack"},
+		Enabled:       true,
+	}
+	disabled := stored
+	disabled.Enabled = false
+
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*alertrule.Rule")).Return(nil)
+	mockRepo.On("List", mock.Anything).Return([]Rule{stored}, nil).Once()
+
+	rule, err := service.Create(context.Background(), nil, bruteForceRequest())
+	require.NoError(t, err)
+	assert.Equal(t, GroupByIP, rule.GroupBy)
+	assert.True(t, rule.Enabled)
+	require.Len(t, auditor.rules, 1)
+	assert.Equal(t, 5*time.Minute, auditor.rules[0].Window)
+
+	mockRepo.On("GetByID", mock.Anything, stored.ID).Return(&stored, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(r *Rule) bool { return !r.Enabled })).Return(nil)
+	mockRepo.On("List", mock.Anything).Return([]Rule{disabled}, nil).Once()
+
+	enabled := false
+	_, err = service.Update(context.Background(), stored.ID, nil, UpdateRuleRequest{Enabled: &enabled})
+	require.NoError(t, err)
+	assert.NotNil(t, auditor.rules, "disabled rules still replace the static thresholds")
+	assert.Empty(t, auditor.rules)
+
+	mockRepo.On("Delete", mock.Anything, stored.ID).Return(nil)
+	mockRepo.On("List", mock.Anything).Return([]Rule{}, nil).Once()
+
+	require.NoError(t, service.Delete(context.Background(), stored.ID, nil))
+	assert.Nil(t, auditor.rules, "the static thresholds are restored once no rule is left")
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreate_Validation tests that unknown event types and channels are rejected
+func TestCreate_Validation(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, &fakeAuditor{})
+
+	req := bruteForceRequest()
+	req.EventTypes = []string{"AUTH_FAILED"}
+	_, err := service.Create(context.Background(), nil, req)
+	assert.ErrorIs(t, err, ErrInvalidEventType)
+
+	req = bruteForceRequest()
+	req.Channels = []string{"pager"}
+	_, err = service.Create(context.Background(), nil, req)
+	assert.ErrorIs(t, err, ErrUnknownChannel)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestDryRun tests that a dry run reports how often a proposed rule would have fired
+func TestDryRun(t *testing.T) {
+	mockRepo := NewMockRepository()
+	auditor := &fakeAuditor{}
+	service := NewService(mockRepo, auditor)
+	service.now = func() time.Time { return testNow }
+	day := time.Date(2026, 7, 8, 22, 0, 0, 0, time.UTC)
+	for i := 0; i < 8; i++ {
+		auditor.events = append(auditor.events, security.SecurityEvent{
+			Type:      security.EventAuthFailure,
+			IPAddress: "10.0.0.1",
+			Timestamp: day.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	auditor.events = append(auditor.events, security.SecurityEvent{
+		Type:      security.EventAuthSuccess,
+		IPAddress: "10.0.0.1",
+		Timestamp: day,
+	})
+
+	result, err := service.DryRun(context.Background(), bruteForceRequest(), 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9, result.EventsReplayed)
+	assert.Equal(t, 8, result.MatchingEvents)
+	assert.Equal(t, 2, result.Fires, "one alert per 5 minute window")
+	assert.Equal(t, 1, result.Groups)
+	assert.Len(t, result.ByDay, DefaultDryRunDays+1)
+	assert.Contains(t, result.ByDay, DayFires{Date: "2026-07-08", Fires: 2})
+	require.Len(t, result.Samples, 2)
+	assert.Equal(t, day.Add(2*time.Minute), result.Samples[0].At)
+	assert.Empty(t, auditor.rules, "a dry run is not applied")
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -77,7 +77,7 @@ func (h *Handler) CreateAPIKey(c *gin.Context) {
 
 	var req CreateAPIKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -182,7 +182,7 @@ func (h *Handler) UpdateAPIKey(c *gin.Context) {
 
 	var req UpdateAPIKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -328,7 +328,7 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 
 	var req CreateWebhookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -440,7 +440,7 @@ func (h *Handler) UpdateWebhook(c *gin.Context) {
 
 	var req UpdateWebhookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -502,7 +502,7 @@ func (h *Handler) TestWebhook(c *gin.Context) {
 
 	var req TestWebhookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
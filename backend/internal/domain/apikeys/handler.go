@@ -58,7 +58,7 @@ func (h *Handler) Create(c *gin.Context) {
 
 	var req CreateAPIKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
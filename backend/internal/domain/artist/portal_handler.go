@@ -126,7 +126,7 @@ func (h *Handler) CreateProfile(c *gin.Context) {
 
 	var req CreateArtistProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -170,7 +170,7 @@ func (h *Handler) UpdateMyProfile(c *gin.Context) {
 
 	var req UpdateArtistProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -311,7 +311,7 @@ func (h *Handler) CreateTechRider(c *gin.Context) {
 
 	var req CreateTechRiderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -406,7 +406,7 @@ func (h *Handler) UpdateTechRider(c *gin.Context) {
 
 	var req UpdateTechRiderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -572,7 +572,7 @@ func (h *Handler) AddAvailability(c *gin.Context) {
 
 	var req CreateAvailabilityRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -610,7 +610,7 @@ func (h *Handler) UpdateAvailability(c *gin.Context) {
 		Notes  string             `json:"notes"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -770,7 +770,7 @@ func (h *Handler) RespondToInvitation(c *gin.Context) {
 
 	var req RespondToInvitationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -908,7 +908,7 @@ func (h *Handler) AddDocument(c *gin.Context) {
 		IsPublic bool         `json:"isPublic"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -1016,7 +1016,7 @@ func (h *Handler) CreateInvitation(c *gin.Context) {
 
 	var req CreateInvitationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -1114,7 +1114,7 @@ func (h *Handler) UpdateInvitationContract(c *gin.Context) {
 		Status      ContractStatus `json:"status" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -0,0 +1,92 @@
+package attestation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentHash returns the hex SHA-256 of the bytes of a document
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidHash reports whether hash is a hex SHA-256
+func ValidHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// RecordHash returns the hex SHA-256 of the canonical form of an attestation: its
+// festival, sequence, document, content hash, previous hash and signing time,
+// separated by newlines
+func RecordHash(a *Attestation) string {
+	canonical := strings.Join([]string{
+		a.FestivalID.String(),
+		strconv.FormatInt(a.Sequence, 10),
+		string(a.DocumentType),
+		a.DocumentID.String(),
+		a.DocumentNumber,
+		a.ContentHash,
+		a.PreviousHash,
+		a.SignedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign returns the hex HMAC-SHA256 of a record hash
+func Sign(key []byte, recordHash string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(recordHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Seal links an attestation to the previous one of the chain, or to the genesis hash
+// when it is the first, and computes its record hash
+func Seal(a *Attestation, previous *Attestation) {
+	a.Sequence = 1
+	a.PreviousHash = GenesisHash
+	if previous != nil {
+		a.Sequence = previous.Sequence + 1
+		a.PreviousHash = previous.RecordHash
+	}
+	a.RecordHash = RecordHash(a)
+}
+
+// CheckLinks checks that the attestations, in sequence order, match their record
+// hashes and each follow on from the previous one. Signatures are checked by the
+// caller, which holds the keys.
+func CheckLinks(attestations []Attestation) []Issue {
+	var issues []Issue
+	previousHash := GenesisHash
+	var previousSequence int64
+	for i := range attestations {
+		a := &attestations[i]
+		if RecordHash(a) != a.RecordHash {
+			issues = append(issues, issueOf(a, ProblemRecordModified))
+		}
+		if a.PreviousHash != previousHash || a.Sequence != previousSequence+1 {
+			issues = append(issues, issueOf(a, ProblemChainBroken))
+		}
+		previousHash = a.RecordHash
+		previousSequence = a.Sequence
+	}
+	return issues
+}
+
+func issueOf(a *Attestation, problem Problem) Issue {
+	return Issue{
+		Sequence:     a.Sequence,
+		DocumentType: a.DocumentType,
+		DocumentID:   a.DocumentID,
+		Problem:      problem,
+	}
+}
@@ -0,0 +1,176 @@
+package attestation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped attestation routes, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	attestations := r.Group("/attestations")
+	{
+		attestations.GET("", h.List)
+		attestations.GET("/verify", h.Verify)
+		attestations.POST("/verify-document", h.VerifyDocument)
+	}
+}
+
+// List lists the attestations of the festival
+// @Summary List document attestations
+// @Description List the signed attestations of the finalized Z-reports and financial exports of the festival, in chain order
+// @Tags attestations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param documentType query string false "Document type" Enums(DAY_CLOSE, REPORT)
+// @Param documentId query string false "Day close or report ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Attestation} "Attestations"
+// @Failure 400 {object} response.ErrorResponse "Invalid filter"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/attestations [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var filter ListFilter
+	if v := c.Query("documentType"); v != "" {
+		docType := DocumentType(strings.ToUpper(v))
+		filter.DocumentType = &docType
+	}
+	if v := c.Query("documentId"); v != "" {
+		documentID, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(c, "INVALID_DOCUMENT_ID", "Invalid document ID", nil)
+			return
+		}
+		filter.DocumentID = &documentID
+	}
+
+	attestations, err := h.service.List(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, attestations)
+}
+
+// Verify checks the attestation chain of the festival
+// @Summary Verify the attestation chain
+// @Description Check that every attestation matches its record hash, links to the previous one and carries a valid server signature, and that the stored Z-reports still match their content hash
+// @Tags attestations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Verification} "Chain verification"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/attestations/verify [get]
+func (h *Handler) Verify(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	verification, err := h.service.Verify(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, verification)
+}
+
+// VerifyDocument checks a document against the attestation chain
+// @Summary Verify a document
+// @Description Check that an exported report or Z-report is exactly as generated. Upload the file as multipart "file", or send its hex SHA-256 as JSON.
+// @Tags attestations
+// @Accept multipart/form-data
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param file formData file false "Document file"
+// @Param request body VerifyDocumentRequest false "Document hash"
+// @Success 200 {object} response.Response{data=DocumentVerification} "Document verification"
+// @Failure 400 {object} response.ErrorResponse "Missing file or invalid hash"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/attestations/verify-document [post]
+func (h *Handler) VerifyDocument(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var contentHash string
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			response.BadRequest(c, "MISSING_FILE", "No file provided", nil)
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			response.BadRequest(c, "INVALID_FILE", "Could not read the file", nil)
+			return
+		}
+		defer file.Close()
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			response.BadRequest(c, "INVALID_FILE", "Could not read the file", nil)
+			return
+		}
+		contentHash = hex.EncodeToString(hash.Sum(nil))
+	} else {
+		var req VerifyDocumentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+		contentHash = req.ContentHash
+	}
+
+	verification, err := h.service.VerifyDocument(c.Request.Context(), festivalID, contentHash)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, verification)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidDocumentType):
+		response.BadRequest(c, "INVALID_DOCUMENT_TYPE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidContentHash):
+		response.BadRequest(c, "INVALID_CONTENT_HASH", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
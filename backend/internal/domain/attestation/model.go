@@ -0,0 +1,115 @@
+package attestation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attestation errors
+var (
+	ErrInvalidDocumentType = errors.New("document type must be DAY_CLOSE or REPORT")
+	ErrInvalidContentHash  = errors.New("content hash must be a hex SHA-256")
+	ErrNoSigningKey        = errors.New("no signing key configured")
+)
+
+// GenesisHash is the previous hash of the first attestation of a festival
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// DocumentType is the kind of finalized document an attestation seals
+type DocumentType string
+
+const (
+	DocumentDayClose DocumentType = "DAY_CLOSE" // Z-report frozen by a day close
+	DocumentReport   DocumentType = "REPORT"    // Financial report export file
+)
+
+// IsValid checks if the document type is valid
+func (t DocumentType) IsValid() bool {
+	return t == DocumentDayClose || t == DocumentReport
+}
+
+// Attestation seals a finalized financial document. The attestations of a festival form
+// a chain: each embeds the record hash of the previous one, so that changing, removing
+// or reordering a sealed document breaks every later link, and each record hash is
+// signed with a key derived from the server keyring.
+type Attestation struct {
+	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID     uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Sequence       int64        `json:"sequence" gorm:"not null"` // Position in the chain of the festival, from 1
+	DocumentType   DocumentType `json:"documentType" gorm:"not null"`
+	DocumentID     uuid.UUID    `json:"documentId" gorm:"type:uuid;not null"`
+	DocumentNumber string       `json:"documentNumber,omitempty"`     // Z-report number or export file name
+	ContentHash    string       `json:"contentHash" gorm:"not null"`  // Hex SHA-256 of the document
+	PreviousHash   string       `json:"previousHash" gorm:"not null"` // Record hash of the previous attestation
+	RecordHash     string       `json:"recordHash" gorm:"not null"`
+	Signature      string       `json:"signature" gorm:"not null"` // Hex HMAC-SHA256 of the record hash
+	KeyFingerprint string       `json:"keyFingerprint" gorm:"not null"`
+	SignedAt       time.Time    `json:"signedAt" gorm:"not null"`
+}
+
+func (Attestation) TableName() string {
+	return "document_attestations"
+}
+
+// AttestRequest seals a document
+type AttestRequest struct {
+	FestivalID     uuid.UUID
+	DocumentType   DocumentType
+	DocumentID     uuid.UUID
+	DocumentNumber string
+	Content        []byte // Exact bytes of the document as finalized
+}
+
+// Problem is what verification found wrong with an attestation
+type Problem string
+
+const (
+	ProblemRecordModified  Problem = "RECORD_MODIFIED"     // The attestation does not match its record hash
+	ProblemChainBroken     Problem = "CHAIN_BROKEN"        // The previous hash or sequence does not follow on
+	ProblemBadSignature    Problem = "BAD_SIGNATURE"       // The signature was not made by the signing key
+	ProblemContentModified Problem = "CONTENT_MODIFIED"    // The stored document no longer matches its content hash
+	ProblemKeyRetired      Problem = "SIGNING_KEY_RETIRED" // Signed with a secret no longer held; links still checked
+)
+
+// Issue is a problem found with one attestation of the chain
+type Issue struct {
+	Sequence     int64        `json:"sequence"`
+	DocumentType DocumentType `json:"documentType"`
+	DocumentID   uuid.UUID    `json:"documentId"`
+	Problem      Problem      `json:"problem"`
+}
+
+// Verification is the result of checking the attestation chain of a festival. The chain
+// is valid when every issue found is a retired signing key.
+type Verification struct {
+	FestivalID       uuid.UUID `json:"festivalId"`
+	Valid            bool      `json:"valid"`
+	Attestations     int       `json:"attestations"`
+	ContentsVerified int       `json:"contentsVerified"` // Documents whose stored content was hashed again
+	HeadHash         string    `json:"headHash"`         // Record hash of the latest attestation
+	Issues           []Issue   `json:"issues"`
+	VerifiedAt       time.Time `json:"verifiedAt"`
+}
+
+// DocumentVerification is the result of checking a document an auditor holds against
+// the attestation chain
+type DocumentVerification struct {
+	ContentHash  string        `json:"contentHash"`
+	Attested     bool          `json:"attested"` // The content was sealed as generated
+	Attestations []Attestation `json:"attestations"`
+	ChainValid   bool          `json:"chainValid"`
+	VerifiedAt   time.Time     `json:"verifiedAt"`
+}
+
+// ListFilter narrows the listed attestations
+type ListFilter struct {
+	DocumentType *DocumentType
+	DocumentID   *uuid.UUID
+}
+
+// VerifyDocumentRequest checks a document by its hash when the file is not uploaded
+type VerifyDocumentRequest struct {
+	ContentHash string `json:"contentHash" binding:"required"`
+}
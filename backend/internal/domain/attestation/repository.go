@@ -0,0 +1,90 @@
+package attestation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	// WithTx returns a repository running its queries in tx, so a document can be
+	// attested in the transaction that finalizes it
+	WithTx(tx *gorm.DB) Repository
+
+	Append(ctx context.Context, attestation *Attestation, seal func(previous *Attestation) error) error
+	List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Attestation, error)
+	FindByContentHash(ctx context.Context, festivalID uuid.UUID, contentHash string) ([]Attestation, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// Append adds an attestation at the end of the chain of its festival. The chain is
+// locked until the transaction ends, so concurrent attestations link to each other
+// instead of to the same predecessor.
+func (r *repository) Append(ctx context.Context, attestation *Attestation, seal func(previous *Attestation) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "attestations:"+attestation.FestivalID.String()).Error; err != nil {
+			return fmt.Errorf("failed to lock attestation chain: %w", err)
+		}
+
+		var previous Attestation
+		err := tx.Where("festival_id = ?", attestation.FestivalID).Order("sequence DESC").First(&previous).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = seal(nil)
+		case err != nil:
+			return fmt.Errorf("failed to get last attestation: %w", err)
+		default:
+			err = seal(&previous)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(attestation).Error; err != nil {
+			return fmt.Errorf("failed to create attestation: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Attestation, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if filter.DocumentType != nil {
+		query = query.Where("document_type = ?", *filter.DocumentType)
+	}
+	if filter.DocumentID != nil {
+		query = query.Where("document_id = ?", *filter.DocumentID)
+	}
+
+	var attestations []Attestation
+	if err := query.Order("sequence").Find(&attestations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list attestations: %w", err)
+	}
+	return attestations, nil
+}
+
+func (r *repository) FindByContentHash(ctx context.Context, festivalID uuid.UUID, contentHash string) ([]Attestation, error) {
+	var attestations []Attestation
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND content_hash = ?", festivalID, contentHash).
+		Order("sequence").
+		Find(&attestations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find attestations: %w", err)
+	}
+	return attestations, nil
+}
@@ -0,0 +1,41 @@
+package attestation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) WithTx(tx *gorm.DB) Repository {
+	args := m.Called(tx)
+	return args.Get(0).(Repository)
+}
+
+func (m *MockRepository) Append(ctx context.Context, attestation *Attestation, seal func(previous *Attestation) error) error {
+	args := m.Called(ctx, attestation, seal)
+	return args.Error(0)
+}
+
+func (m *MockRepository) List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Attestation, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Attestation), args.Error(1)
+}
+
+func (m *MockRepository) FindByContentHash(ctx context.Context, festivalID uuid.UUID, contentHash string) ([]Attestation, error) {
+	args := m.Called(ctx, festivalID, contentHash)
+	return args.Get(0).([]Attestation), args.Error(1)
+}
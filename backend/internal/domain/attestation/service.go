@@ -0,0 +1,199 @@
+package attestation
+
+import (
+	"context"
+	"crypto/hmac"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"gorm.io/gorm"
+)
+
+// ContentSource returns the stored content of the documents of a type exactly as they
+// were attested, or nil when the document no longer exists; satisfied by
+// dayclose.Service for Z-reports
+type ContentSource interface {
+	DocumentContent(ctx context.Context, festivalID, documentID uuid.UUID) ([]byte, error)
+}
+
+// Service seals finalized financial documents in a signed hash chain per festival and
+// verifies the chain, so that auditors can prove documents were not modified after
+// they were generated
+type Service struct {
+	repo    Repository
+	keyring *security.Keyring
+	sources map[DocumentType]ContentSource
+	now     func() time.Time
+}
+
+// NewService creates an attestation service signing with keys derived from keyring
+func NewService(repo Repository, keyring *security.Keyring) *Service {
+	return &Service{
+		repo:    repo,
+		keyring: keyring,
+		sources: make(map[DocumentType]ContentSource),
+		now:     time.Now,
+	}
+}
+
+// SetContentSource lets verification hash the stored documents of a type again
+func (s *Service) SetContentSource(docType DocumentType, source ContentSource) {
+	s.sources[docType] = source
+}
+
+// Attest seals a document at the end of the chain of its festival
+func (s *Service) Attest(ctx context.Context, req AttestRequest) (*Attestation, error) {
+	return s.attest(ctx, s.repo, req)
+}
+
+// AttestTx seals a document within tx; the attestation is only kept if tx commits,
+// along with the document it seals
+func (s *Service) AttestTx(ctx context.Context, tx *gorm.DB, req AttestRequest) (*Attestation, error) {
+	return s.attest(ctx, s.repo.WithTx(tx), req)
+}
+
+func (s *Service) attest(ctx context.Context, repo Repository, req AttestRequest) (*Attestation, error) {
+	if !req.DocumentType.IsValid() {
+		return nil, ErrInvalidDocumentType
+	}
+	if s.keyring == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	secret := s.keyring.Current()
+	a := &Attestation{
+		ID:             uuid.New(),
+		FestivalID:     req.FestivalID,
+		DocumentType:   req.DocumentType,
+		DocumentID:     req.DocumentID,
+		DocumentNumber: req.DocumentNumber,
+		ContentHash:    ContentHash(req.Content),
+		KeyFingerprint: security.Fingerprint(secret),
+		// Stored with the precision of the database, so the record hash can be computed again
+		SignedAt: s.now().UTC().Truncate(time.Microsecond),
+	}
+	err := repo.Append(ctx, a, func(previous *Attestation) error {
+		Seal(a, previous)
+		a.Signature = Sign(security.DocumentSigningKey(secret, a.FestivalID.String()), a.RecordHash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// List lists the attestations of a festival in chain order
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Attestation, error) {
+	if filter.DocumentType != nil && !filter.DocumentType.IsValid() {
+		return nil, ErrInvalidDocumentType
+	}
+	return s.repo.List(ctx, festivalID, filter)
+}
+
+// Verify checks the whole chain of a festival: every attestation must match its record
+// hash, link to the previous one and carry a valid signature, and the documents with a
+// content source must still match their content hash. Signatures made with a secret
+// that is no longer held cannot be checked and are reported without failing the chain.
+func (s *Service) Verify(ctx context.Context, festivalID uuid.UUID) (*Verification, error) {
+	attestations, err := s.repo.List(ctx, festivalID, ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &Verification{
+		FestivalID:   festivalID,
+		Attestations: len(attestations),
+		HeadHash:     GenesisHash,
+		Issues:       CheckLinks(attestations),
+		VerifiedAt:   s.now(),
+	}
+	if len(attestations) > 0 {
+		verification.HeadHash = attestations[len(attestations)-1].RecordHash
+	}
+
+	for i := range attestations {
+		a := &attestations[i]
+		if problem := s.checkSignature(a); problem != "" {
+			verification.Issues = append(verification.Issues, issueOf(a, problem))
+		}
+
+		source := s.sources[a.DocumentType]
+		if source == nil {
+			continue
+		}
+		content, err := source.DocumentContent(ctx, festivalID, a.DocumentID)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil || ContentHash(content) != a.ContentHash {
+			verification.Issues = append(verification.Issues, issueOf(a, ProblemContentModified))
+		}
+		verification.ContentsVerified++
+	}
+
+	sort.SliceStable(verification.Issues, func(i, j int) bool {
+		return verification.Issues[i].Sequence < verification.Issues[j].Sequence
+	})
+	verification.Valid = true
+	for _, issue := range verification.Issues {
+		if issue.Problem != ProblemKeyRetired {
+			verification.Valid = false
+		}
+	}
+	if verification.Issues == nil {
+		verification.Issues = []Issue{}
+	}
+	return verification, nil
+}
+
+// VerifyDocument checks whether a document with the given hex SHA-256 was attested by
+// the festival, and whether the chain holding it is intact
+func (s *Service) VerifyDocument(ctx context.Context, festivalID uuid.UUID, contentHash string) (*DocumentVerification, error) {
+	contentHash = strings.ToLower(strings.TrimSpace(contentHash))
+	if !ValidHash(contentHash) {
+		return nil, ErrInvalidContentHash
+	}
+
+	attestations, err := s.repo.FindByContentHash(ctx, festivalID, contentHash)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.Verify(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attestations == nil {
+		attestations = []Attestation{}
+	}
+	return &DocumentVerification{
+		ContentHash:  contentHash,
+		Attested:     len(attestations) > 0,
+		Attestations: attestations,
+		ChainValid:   chain.Valid,
+		VerifiedAt:   chain.VerifiedAt,
+	}, nil
+}
+
+// checkSignature checks the signature of an attestation with the secret it was signed
+// with, found by its fingerprint among the secrets of the keyring
+func (s *Service) checkSignature(a *Attestation) Problem {
+	if s.keyring == nil {
+		return ProblemKeyRetired
+	}
+	for _, secret := range s.keyring.Accepted() {
+		if security.Fingerprint(secret) != a.KeyFingerprint {
+			continue
+		}
+		expected := Sign(security.DocumentSigningKey(secret, a.FestivalID.String()), a.RecordHash)
+		if !hmac.Equal([]byte(expected), []byte(a.Signature)) {
+			return ProblemBadSignature
+		}
+		return ""
+	}
+	return ProblemKeyRetired
+}
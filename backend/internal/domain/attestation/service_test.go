@@ -0,0 +1,172 @@
+package attestation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource map[uuid.UUID][]byte
+
+func (s fakeSource) DocumentContent(ctx context.Context, festivalID, documentID uuid.UUID) ([]byte, error) {
+	return s[documentID], nil
+}
+
+// attestAll seals one Z-report and one export, returning their IDs and the chain
+// appended to the repository
+func attestAll(t *testing.T, service *Service, mockRepo *MockRepository, festivalID uuid.UUID, source fakeSource) (uuid.UUID, uuid.UUID, []Attestation) {
+	var chain []Attestation
+	mockRepo.On("Append", mock.Anything, mock.AnythingOfType("*attestation.Attestation"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			var previous *Attestation
+			if len(chain) > 0 {
+				previous = &chain[len(chain)-1]
+			}
+			attestation := args.Get(1).(*Attestation)
+			require.NoError(t, args.Get(2).(func(*Attestation) error)(previous))
+			chain = append(chain, *attestation)
+		}).
+		Return(nil).Twice()
+
+	closeID, reportID := uuid.New(), uuid.New()
+	source[closeID] = []byte(`{"number":"Z-2026-000001","netSales":125000}`)
+
+	_, err := service.Attest(context.Background(), AttestRequest{
+		FestivalID: festivalID, DocumentType: DocumentDayClose, DocumentID: closeID,
+		DocumentNumber: "Z-2026-000001", Content: source[closeID],
+	})
+	require.NoError(t, err)
+	_, err = service.Attest(context.Background(), AttestRequest{
+		FestivalID: festivalID, DocumentType: DocumentReport, DocumentID: reportID,
+		DocumentNumber: "SALES_0a1b2c3d.csv", Content: []byte("date,amount\n2026-07-18,1250.00\n"),
+	})
+	require.NoError(t, err)
+	return closeID, reportID, chain
+}
+
+// expectChain serves the chain of a festival to the next verification
+func expectChain(mockRepo *MockRepository, festivalID uuid.UUID, chain []Attestation) {
+	mockRepo.On("List", mock.Anything, festivalID, ListFilter{}).Return(chain, nil).Once()
+}
+
+func newTestService(keyring *security.Keyring) (*Service, *MockRepository, fakeSource) {
+	mockRepo := NewMockRepository()
+	source := fakeSource{}
+	service := NewService(mockRepo, keyring)
+	service.SetContentSource(DocumentDayClose, source)
+	service.now = func() time.Time { return time.Date(2026, 7, 19, 6, 0, 0, 123456789, time.UTC) }
+	return service, mockRepo, source
+}
+
+func TestService_Attest_ChainsDocuments(t *testing.T) {
+	service, mockRepo, source := newTestService(security.NewKeyring("secret-a", nil, time.Hour))
+	festivalID := uuid.New()
+	_, _, chain := attestAll(t, service, mockRepo, festivalID, source)
+
+	require.Len(t, chain, 2)
+	first, second := chain[0], chain[1]
+	assert.Equal(t, int64(1), first.Sequence)
+	assert.Equal(t, GenesisHash, first.PreviousHash)
+	assert.Equal(t, int64(2), second.Sequence)
+	assert.Equal(t, first.RecordHash, second.PreviousHash, "each attestation embeds the hash of the previous one")
+	assert.Equal(t, 123456000, first.SignedAt.Nanosecond(), "signing time kept at database precision")
+
+	expectChain(mockRepo, festivalID, chain)
+	verification, err := service.Verify(context.Background(), festivalID)
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 2, verification.Attestations)
+	assert.Equal(t, 1, verification.ContentsVerified)
+	assert.Equal(t, second.RecordHash, verification.HeadHash)
+	assert.Empty(t, verification.Issues)
+}
+
+func TestService_Verify_DetectsTampering(t *testing.T) {
+	service, mockRepo, source := newTestService(security.NewKeyring("secret-a", nil, time.Hour))
+	festivalID := uuid.New()
+	closeID, _, chain := attestAll(t, service, mockRepo, festivalID, source)
+
+	// The stored Z-report changed after the close
+	source[closeID] = []byte(`{"number":"Z-2026-000001","netSales":99000}`)
+	expectChain(mockRepo, festivalID, chain)
+	verification, err := service.Verify(context.Background(), festivalID)
+	require.NoError(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, []Issue{{Sequence: 1, DocumentType: DocumentDayClose, DocumentID: closeID, Problem: ProblemContentModified}}, verification.Issues)
+
+	// The attested hash was rewritten to match, which breaks the record and the next link
+	source[closeID] = []byte(`{"number":"Z-2026-000001","netSales":125000}`)
+	chain[0].ContentHash = ContentHash([]byte("forged"))
+	expectChain(mockRepo, festivalID, chain)
+	verification, err = service.Verify(context.Background(), festivalID)
+	require.NoError(t, err)
+	assert.False(t, verification.Valid)
+	problems := make([]Problem, len(verification.Issues))
+	for i, issue := range verification.Issues {
+		problems[i] = issue.Problem
+	}
+	assert.ElementsMatch(t, []Problem{ProblemRecordModified, ProblemContentModified}, problems)
+
+	// A record rebuilt and relinked without the signing key
+	chain[0].RecordHash = RecordHash(&chain[0])
+	chain[1].PreviousHash = chain[0].RecordHash
+	chain[1].RecordHash = RecordHash(&chain[1])
+	expectChain(mockRepo, festivalID, chain)
+	verification, err = service.Verify(context.Background(), festivalID)
+	require.NoError(t, err)
+	assert.False(t, verification.Valid)
+	assert.Contains(t, verification.Issues, Issue{Sequence: 2, DocumentType: DocumentReport, DocumentID: chain[1].DocumentID, Problem: ProblemBadSignature})
+
+	// A removed attestation breaks the chain
+	expectChain(mockRepo, festivalID, chain[1:])
+	verification, err = service.Verify(context.Background(), festivalID)
+	require.NoError(t, err)
+	assert.Contains(t, verification.Issues, Issue{Sequence: 2, DocumentType: DocumentReport, DocumentID: chain[1].DocumentID, Problem: ProblemChainBroken})
+}
+
+func TestService_Verify_RetiredKey(t *testing.T) {
+	keyring := security.NewKeyring("secret-a", nil, 0)
+	service, mockRepo, source := newTestService(keyring)
+	festivalID := uuid.New()
+	_, _, chain := attestAll(t, service, mockRepo, festivalID, source)
+
+	keyring.Rotate("secret-b", nil)
+	expectChain(mockRepo, festivalID, chain)
+	verification, err := service.Verify(context.Background(), festivalID)
+	require.NoError(t, err)
+	assert.True(t, verification.Valid, "links and contents still prove the chain intact")
+	require.Len(t, verification.Issues, 2)
+	assert.Equal(t, ProblemKeyRetired, verification.Issues[0].Problem)
+}
+
+func TestService_VerifyDocument(t *testing.T) {
+	service, mockRepo, source := newTestService(security.NewKeyring("secret-a", nil, time.Hour))
+	festivalID := uuid.New()
+	_, reportID, chain := attestAll(t, service, mockRepo, festivalID, source)
+
+	hash := ContentHash([]byte("date,amount\n2026-07-18,1250.00\n"))
+	mockRepo.On("FindByContentHash", mock.Anything, festivalID, hash).Return([]Attestation{chain[1]}, nil).Once()
+	expectChain(mockRepo, festivalID, chain)
+	verification, err := service.VerifyDocument(context.Background(), festivalID, " "+hash+" ")
+	require.NoError(t, err)
+	assert.True(t, verification.Attested)
+	assert.True(t, verification.ChainValid)
+	require.Len(t, verification.Attestations, 1)
+	assert.Equal(t, reportID, verification.Attestations[0].DocumentID)
+
+	edited := ContentHash([]byte("date,amount\n2026-07-18,12.50\n"))
+	mockRepo.On("FindByContentHash", mock.Anything, festivalID, edited).Return([]Attestation(nil), nil).Once()
+	expectChain(mockRepo, festivalID, chain)
+	verification, err = service.VerifyDocument(context.Background(), festivalID, edited)
+	require.NoError(t, err)
+	assert.False(t, verification.Attested, "an edited export matches no attestation")
+
+	_, err = service.VerifyDocument(context.Background(), festivalID, "abc")
+	assert.ErrorIs(t, err, ErrInvalidContentHash)
+}
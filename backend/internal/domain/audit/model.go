@@ -64,6 +64,10 @@ const (
 	ActionWalletPayment   AuditAction = "WALLET_PAYMENT"
 	ActionWalletRefund    AuditAction = "WALLET_REFUND"
 	ActionWalletTransfer  AuditAction = "WALLET_TRANSFER"
+	ActionWalletCredentialReplace AuditAction = "WALLET_CREDENTIAL_REPLACE"
+	ActionWalletBatchCreate  AuditAction = "WALLET_BATCH_CREATE"  // Bulk credit or debit, awaiting approval or queued
+	ActionWalletBatchApprove AuditAction = "WALLET_BATCH_APPROVE" // Reviewed by a second organizer
+	ActionWalletBatchReject  AuditAction = "WALLET_BATCH_REJECT"  // Whole batch or some of its wallets
 
 	// Order actions
 	ActionOrderCreate AuditAction = "ORDER_CREATE"
@@ -98,11 +102,19 @@ const (
 	ActionSettingsUpdate AuditAction = "SETTINGS_UPDATE"
 	ActionAPIKeyCreate   AuditAction = "API_KEY_CREATE"
 	ActionAPIKeyRevoke   AuditAction = "API_KEY_REVOKE"
+	ActionKeyRotate      AuditAction = "KEY_ROTATE" // Signing secret or Auth0 key rotation
+	ActionKeyRevoke      AuditAction = "KEY_REVOKE"
 
 	// Data export actions
 	ActionDataExport AuditAction = "DATA_EXPORT"
 	ActionReportGenerate AuditAction = "REPORT_GENERATE"
 
+	// Support actions
+	ActionSupportSearch AuditAction = "SUPPORT_SEARCH" // Search of orders and transactions by support staff
+
+	// Operations actions
+	ActionRunbookRun AuditAction = "RUNBOOK_RUN" // Operational fix run by an admin, dry runs included
+
 	// Generic CRUD actions
 	ActionCreate AuditAction = "CREATE"
 	ActionRead   AuditAction = "READ"
@@ -120,7 +132,8 @@ func (a AuditAction) IsValid() bool {
 		ActionTicketCreate: true, ActionTicketUpdate: true, ActionTicketValidate: true,
 		ActionTicketTransfer: true, ActionTicketRefund: true,
 		ActionWalletCreate: true, ActionWalletTopup: true, ActionWalletPayment: true,
-		ActionWalletRefund: true, ActionWalletTransfer: true,
+		ActionWalletRefund: true, ActionWalletTransfer: true, ActionWalletCredentialReplace: true,
+		ActionWalletBatchCreate: true, ActionWalletBatchApprove: true, ActionWalletBatchReject: true,
 		ActionOrderCreate: true, ActionOrderUpdate: true, ActionOrderCancel: true, ActionOrderRefund: true,
 		ActionStandCreate: true, ActionStandUpdate: true, ActionStandDelete: true,
 		ActionProductCreate: true, ActionProductUpdate: true, ActionProductDelete: true,
@@ -128,7 +141,10 @@ func (a AuditAction) IsValid() bool {
 		ActionStageCreate: true, ActionStageUpdate: true, ActionStageDelete: true,
 		ActionSecurityAlert: true, ActionAccessDenied: true, ActionSuspiciousActivity: true,
 		ActionSettingsUpdate: true, ActionAPIKeyCreate: true, ActionAPIKeyRevoke: true,
+		ActionKeyRotate: true, ActionKeyRevoke: true,
 		ActionDataExport: true, ActionReportGenerate: true,
+		ActionSupportSearch: true,
+		ActionRunbookRun: true,
 		ActionCreate: true, ActionRead: true, ActionUpdate: true, ActionDelete: true,
 	}
 	return validActions[a]
@@ -150,7 +166,8 @@ func (a AuditAction) Category() string {
 		return "festival_management"
 	case ActionTicketCreate, ActionTicketUpdate, ActionTicketValidate, ActionTicketTransfer, ActionTicketRefund:
 		return "ticketing"
-	case ActionWalletCreate, ActionWalletTopup, ActionWalletPayment, ActionWalletRefund, ActionWalletTransfer:
+	case ActionWalletCreate, ActionWalletTopup, ActionWalletPayment, ActionWalletRefund, ActionWalletTransfer,
+		ActionWalletCredentialReplace, ActionWalletBatchCreate, ActionWalletBatchApprove, ActionWalletBatchReject:
 		return "payments"
 	case ActionOrderCreate, ActionOrderUpdate, ActionOrderCancel, ActionOrderRefund:
 		return "orders"
@@ -162,10 +179,14 @@ func (a AuditAction) Category() string {
 		return "lineup"
 	case ActionSecurityAlert, ActionAccessDenied, ActionSuspiciousActivity:
 		return "security"
-	case ActionSettingsUpdate, ActionAPIKeyCreate, ActionAPIKeyRevoke:
+	case ActionSettingsUpdate, ActionAPIKeyCreate, ActionAPIKeyRevoke, ActionKeyRotate, ActionKeyRevoke:
 		return "configuration"
 	case ActionDataExport, ActionReportGenerate:
 		return "exports"
+	case ActionSupportSearch:
+		return "support"
+	case ActionRunbookRun:
+		return "operations"
 	default:
 		return "general"
 	}
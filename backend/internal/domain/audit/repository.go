@@ -319,14 +319,16 @@ func getActionsForCategory(category string) []AuditAction {
 		"user_management": {ActionUserCreate, ActionUserUpdate, ActionUserDelete, ActionUserBan, ActionUserUnban, ActionRoleChange},
 		"festival_management": {ActionFestivalCreate, ActionFestivalUpdate, ActionFestivalDelete},
 		"ticketing": {ActionTicketCreate, ActionTicketUpdate, ActionTicketValidate, ActionTicketTransfer, ActionTicketRefund},
-		"payments": {ActionWalletCreate, ActionWalletTopup, ActionWalletPayment, ActionWalletRefund, ActionWalletTransfer},
+		"payments": {ActionWalletCreate, ActionWalletTopup, ActionWalletPayment, ActionWalletRefund, ActionWalletTransfer, ActionWalletCredentialReplace},
 		"orders": {ActionOrderCreate, ActionOrderUpdate, ActionOrderCancel, ActionOrderRefund},
 		"stands": {ActionStandCreate, ActionStandUpdate, ActionStandDelete},
 		"products": {ActionProductCreate, ActionProductUpdate, ActionProductDelete},
 		"lineup": {ActionArtistCreate, ActionArtistUpdate, ActionArtistDelete, ActionStageCreate, ActionStageUpdate, ActionStageDelete},
 		"security": {ActionSecurityAlert, ActionAccessDenied, ActionSuspiciousActivity},
-		"configuration": {ActionSettingsUpdate, ActionAPIKeyCreate, ActionAPIKeyRevoke},
+		"configuration": {ActionSettingsUpdate, ActionAPIKeyCreate, ActionAPIKeyRevoke, ActionKeyRotate, ActionKeyRevoke},
 		"exports": {ActionDataExport, ActionReportGenerate},
+		"support": {ActionSupportSearch},
+		"operations": {ActionRunbookRun},
 		"general": {ActionCreate, ActionRead, ActionUpdate, ActionDelete},
 	}
 	return categoryMap[category]
@@ -0,0 +1,475 @@
+package banktransfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// maxStatementSize is the largest statement file accepted
+const maxStatementSize = 10 << 20
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped top-up orders and bank transfers, which
+// should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	orders := r.Group("/bank-transfer-orders")
+	{
+		orders.GET("", h.ListOrders)
+		orders.POST("", h.CreateOrder)
+		orders.GET("/:orderId", h.GetOrder)
+		orders.POST("/:orderId/cancel", h.CancelOrder)
+		orders.POST("/:orderId/credit", h.RetryCredit)
+	}
+
+	transfers := r.Group("/bank-transfers")
+	{
+		transfers.GET("", h.ListTransfers)
+		transfers.POST("/import", h.ImportStatement)
+		transfers.GET("/:transferId", h.GetTransfer)
+		transfers.POST("/:transferId/match", h.MatchTransfer)
+		transfers.POST("/:transferId/dismiss", h.DismissTransfer)
+	}
+}
+
+// ListOrders lists the top-up orders of the festival
+// @Summary List bank transfer top-up orders
+// @Description List the top-up orders paid by bank transfer, latest first
+// @Tags bank-transfers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "PENDING, PAID or CANCELLED"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Order,meta=response.Meta} "Top-up orders"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfer-orders [get]
+func (h *Handler) ListOrders(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := pagination(c)
+	orders, total, err := h.service.ListOrders(c.Request.Context(), festivalID, OrderFilter{
+		Status: OrderStatus(c.Query("status")),
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, orders, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// CreateOrder opens a top-up order paid by bank transfer
+// @Summary Create a bank transfer top-up order
+// @Description Open a top-up order crediting one or many active wallets of the festival, e.g. the group booking of a company, once its bank transfer is received. The response holds the payment instructions to send to the payer: the IBAN to pay, the virtual IBAN of the order when a provider is configured, and the reference to quote.
+// @Tags bank-transfers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateOrderRequest true "Payer and wallets to credit"
+// @Success 201 {object} response.Response{data=OrderResponse} "Top-up order with its payment instructions"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 422 {object} response.ErrorResponse "Wallet not found or not active"
+// @Failure 503 {object} response.ErrorResponse "Bank transfers not configured"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfer-orders [post]
+func (h *Handler) CreateOrder(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	order, err := h.service.CreateOrder(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, order)
+}
+
+// GetOrder returns a top-up order
+// @Summary Get a bank transfer top-up order
+// @Description Get a top-up order with its payment instructions and the credit of each wallet
+// @Tags bank-transfers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param orderId path string true "Order ID" format(uuid)
+// @Success 200 {object} response.Response{data=OrderResponse} "Top-up order"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfer-orders/{orderId} [get]
+func (h *Handler) GetOrder(c *gin.Context) {
+	festivalID, orderID, ok := idParams(c, "orderId")
+	if !ok {
+		return
+	}
+
+	order, err := h.service.GetOrder(c.Request.Context(), festivalID, orderID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, order)
+}
+
+// CancelOrder cancels a pending top-up order
+// @Summary Cancel a bank transfer top-up order
+// @Description Cancel a pending top-up order and close its virtual IBAN. A transfer still quoting its reference is flagged for review.
+// @Tags bank-transfers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param orderId path string true "Order ID" format(uuid)
+// @Success 200 {object} response.Response{data=Order} "Order cancelled"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Order no longer pending"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfer-orders/{orderId}/cancel [post]
+func (h *Handler) CancelOrder(c *gin.Context) {
+	festivalID, orderID, ok := idParams(c, "orderId")
+	if !ok {
+		return
+	}
+
+	order, err := h.service.CancelOrder(c.Request.Context(), festivalID, orderID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, order)
+}
+
+// RetryCredit credits again the wallets of a paid order that could not be credited
+// @Summary Retry the credits of a paid order
+// @Description Credit again the wallets of a paid top-up order whose credit failed, e.g. a wallet frozen when the transfer was received. The wallets already credited are not credited twice.
+// @Tags bank-transfers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param orderId path string true "Order ID" format(uuid)
+// @Success 200 {object} response.Response{data=Order} "Order with the outcome of each credit"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Order not paid"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfer-orders/{orderId}/credit [post]
+func (h *Handler) RetryCredit(c *gin.Context) {
+	festivalID, orderID, ok := idParams(c, "orderId")
+	if !ok {
+		return
+	}
+
+	order, err := h.service.RetryCredit(c.Request.Context(), festivalID, orderID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, order)
+}
+
+// ListTransfers lists the incoming bank transfers of the festival
+// @Summary List bank transfers
+// @Description List the incoming bank transfers, latest booked first. Filter on UNMATCHED for the transfers waiting for a review.
+// @Tags bank-transfers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "MATCHED, UNMATCHED or DISMISSED"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Transfer,meta=response.Meta} "Bank transfers"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfers [get]
+func (h *Handler) ListTransfers(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := pagination(c)
+	transfers, total, err := h.service.ListTransfers(c.Request.Context(), festivalID, TransferFilter{
+		Status: TransferStatus(c.Query("status")),
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, transfers, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// ImportStatement imports a bank statement of the collection account
+// @Summary Import a bank statement
+// @Description Import a camt.053 or MT940 statement of the collection account. Its credits are matched to the pending top-up orders by reference or virtual IBAN and the wallets credited; the others are flagged for review. Credits imported earlier are skipped, so overlapping statements can be imported.
+// @Tags bank-transfers
+// @Accept multipart/form-data
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param file formData file true "camt.053 or MT940 statement"
+// @Success 200 {object} response.Response{data=ImportResult} "Import summary"
+// @Failure 400 {object} response.ErrorResponse "Invalid statement"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfers/import [post]
+func (h *Handler) ImportStatement(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "MISSING_FILE", "No file provided", nil)
+		return
+	}
+	if header.Size > maxStatementSize {
+		response.BadRequest(c, "PAYLOAD_TOO_LARGE", fmt.Sprintf("Statement must be under %d MB", maxStatementSize>>20), nil)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.BadRequest(c, "INVALID_FILE", "Could not read the file", nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FILE", "Could not read the file", nil)
+		return
+	}
+
+	result, err := h.service.ImportStatement(c.Request.Context(), festivalID, data)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// GetTransfer returns a bank transfer
+// @Summary Get a bank transfer
+// @Description Get an incoming bank transfer with the order it paid, or why it was not matched
+// @Tags bank-transfers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param transferId path string true "Transfer ID" format(uuid)
+// @Success 200 {object} response.Response{data=Transfer} "Bank transfer"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Transfer not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfers/{transferId} [get]
+func (h *Handler) GetTransfer(c *gin.Context) {
+	festivalID, transferID, ok := idParams(c, "transferId")
+	if !ok {
+		return
+	}
+
+	transfer, err := h.service.GetTransfer(c.Request.Context(), festivalID, transferID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, transfer)
+}
+
+// MatchTransfer matches an unmatched transfer to a pending order by hand
+// @Summary Match a bank transfer
+// @Description Match an unmatched transfer to a pending top-up order of the same amount, e.g. when the payer mistyped the reference, and credit its wallets. An expired order can still be paid.
+// @Tags bank-transfers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param transferId path string true "Transfer ID" format(uuid)
+// @Param request body MatchRequest true "Order paid by the transfer"
+// @Success 200 {object} response.Response{data=Transfer} "Transfer matched"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Transfer or order not found"
+// @Failure 409 {object} response.ErrorResponse "Transfer already reviewed or order no longer pending"
+// @Failure 422 {object} response.ErrorResponse "Amount or currency differs from the order"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfers/{transferId}/match [post]
+func (h *Handler) MatchTransfer(c *gin.Context) {
+	festivalID, transferID, ok := idParams(c, "transferId")
+	if !ok {
+		return
+	}
+
+	var req MatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	transfer, err := h.service.MatchTransfer(c.Request.Context(), festivalID, transferID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, transfer)
+}
+
+// DismissTransfer closes the review of an unmatched transfer
+// @Summary Dismiss a bank transfer
+// @Description Close the review of an unmatched transfer without crediting anything, e.g. once it was returned to the payer
+// @Tags bank-transfers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param transferId path string true "Transfer ID" format(uuid)
+// @Param request body DismissRequest true "Review note"
+// @Success 200 {object} response.Response{data=Transfer} "Transfer dismissed"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Transfer not found"
+// @Failure 409 {object} response.ErrorResponse "Transfer already reviewed"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bank-transfers/{transferId}/dismiss [post]
+func (h *Handler) DismissTransfer(c *gin.Context) {
+	festivalID, transferID, ok := idParams(c, "transferId")
+	if !ok {
+		return
+	}
+
+	var req DismissRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	transfer, err := h.service.DismissTransfer(c.Request.Context(), festivalID, transferID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, transfer)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOrderNotFound), errors.Is(err, ErrTransferNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrNoLines):
+		response.BadRequest(c, "NO_WALLETS", err.Error(), nil)
+	case errors.Is(err, ErrTooManyLines):
+		response.BadRequest(c, "TOO_MANY_WALLETS", fmt.Sprintf("An order credits at most %d wallets", MaxLines), nil)
+	case errors.Is(err, ErrDuplicateWallet):
+		response.BadRequest(c, "DUPLICATE_WALLET", err.Error(), nil)
+	case errors.Is(err, ErrInvalidStatement):
+		response.BadRequest(c, "INVALID_STATEMENT", err.Error(), nil)
+	case errors.Is(err, ErrWalletNotFound), errors.Is(err, ErrWalletNotActive),
+		errors.Is(err, ErrAmountMismatch), errors.Is(err, ErrCurrencyMismatch):
+		response.UnprocessableEntity(c, err.Error(), nil)
+	case errors.Is(err, ErrOrderNotPending):
+		response.Conflict(c, "ORDER_NOT_PENDING", err.Error())
+	case errors.Is(err, ErrOrderNotPaid):
+		response.Conflict(c, "ORDER_NOT_PAID", err.Error())
+	case errors.Is(err, ErrTransferReviewed):
+		response.Conflict(c, "TRANSFER_REVIEWED", err.Error())
+	case errors.Is(err, ErrCollectionAccount):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func idParams(c *gin.Context, param string) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, id, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package banktransfer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bank transfer errors
+var (
+	ErrOrderNotFound     = errors.New("top-up order not found")
+	ErrTransferNotFound  = errors.New("bank transfer not found")
+	ErrNoLines           = errors.New("a top-up order credits at least one wallet")
+	ErrTooManyLines      = errors.New("top-up order has too many wallets")
+	ErrDuplicateWallet   = errors.New("a wallet is listed twice in the order")
+	ErrWalletNotFound    = errors.New("wallet not found in the festival")
+	ErrWalletNotActive   = errors.New("wallet is not active")
+	ErrOrderNotPending   = errors.New("top-up order is no longer pending")
+	ErrOrderNotPaid      = errors.New("top-up order is not paid")
+	ErrTransferReviewed  = errors.New("bank transfer was already matched or dismissed")
+	ErrAmountMismatch    = errors.New("transfer amount differs from the order amount")
+	ErrCurrencyMismatch  = errors.New("transfer currency differs from the order currency")
+	ErrInvalidStatement  = errors.New("statement is not a camt.053 or MT940 file")
+	ErrCollectionAccount = errors.New("bank transfers are not configured")
+)
+
+// MaxLines is the largest number of wallets credited by an order
+const MaxLines = 500
+
+// OrderStatus is the state of a top-up order
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "PENDING"   // Waiting for the transfer
+	OrderPaid      OrderStatus = "PAID"      // Transfer received, wallets credited
+	OrderCancelled OrderStatus = "CANCELLED" // Transfers are no longer matched to it
+)
+
+// TransferStatus is the state of an incoming bank transfer
+type TransferStatus string
+
+const (
+	TransferMatched   TransferStatus = "MATCHED"   // Paid a top-up order
+	TransferUnmatched TransferStatus = "UNMATCHED" // Waiting for a manual review
+	TransferDismissed TransferStatus = "DISMISSED" // Reviewed without crediting anything, e.g. returned to the payer
+)
+
+// TransferSource is how a bank transfer was received
+type TransferSource string
+
+const (
+	SourceCAMT053  TransferSource = "CAMT053"  // Imported from an ISO 20022 statement
+	SourceMT940    TransferSource = "MT940"    // Imported from a SWIFT MT940 statement
+	SourceProvider TransferSource = "PROVIDER" // Notified by the virtual IBAN provider
+)
+
+// Line is a wallet credited by an order
+type Line struct {
+	WalletID      uuid.UUID  `json:"walletId"`
+	Amount        int64      `json:"amount"`        // In cents
+	TransactionID uuid.UUID  `json:"transactionId"` // Chosen upfront so that retries credit once
+	CreditedAt    *time.Time `json:"creditedAt,omitempty"`
+	Error         string     `json:"error,omitempty"` // Why the wallet could not be credited
+}
+
+// Order is a top-up of one or many wallets paid by bank transfer, e.g. by a company
+// pre-paying the wallets of its group booking. The payer quotes its reference, or pays
+// to its virtual IBAN, so that the transfer is matched to it.
+type Order struct {
+	ID          uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Reference   string      `json:"reference" gorm:"uniqueIndex;not null"` // ISO 11649 creditor reference, e.g. RF18539007547034
+	VirtualIBAN *string     `json:"virtualIban,omitempty" gorm:"column:virtual_iban;uniqueIndex"`
+	PayerName   string      `json:"payerName"`
+	PayerEmail  string      `json:"payerEmail,omitempty"`
+	Amount      int64       `json:"amount"` // Sum of the lines in cents
+	Currency    string      `json:"currency"`
+	Lines       []Line      `json:"lines" gorm:"type:jsonb;serializer:json"`
+	Status      OrderStatus `json:"status" gorm:"default:'PENDING'"`
+	TransferID  *uuid.UUID  `json:"transferId,omitempty" gorm:"type:uuid"`
+	ExpiresAt   time.Time   `json:"expiresAt"` // Later transfers are flagged for review
+	PaidAt      *time.Time  `json:"paidAt,omitempty"`
+	CreatedBy   *uuid.UUID  `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+func (Order) TableName() string {
+	return "bank_transfer_orders"
+}
+
+// Credited reports whether every wallet of the order was credited
+func (o *Order) Credited() bool {
+	for _, line := range o.Lines {
+		if line.CreditedAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Transfer is an incoming bank transfer to the collection account of a festival
+type Transfer struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID     uuid.UUID      `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Source         TransferSource `json:"source" gorm:"not null"`
+	BankReference  string         `json:"bankReference" gorm:"not null"` // Unique per festival, so that statements can be imported again
+	Amount         int64          `json:"amount"`                        // In cents
+	Currency       string         `json:"currency"`
+	BookedAt       time.Time      `json:"bookedAt"`
+	PayerName      string         `json:"payerName,omitempty"`
+	PayerIBAN      string         `json:"payerIban,omitempty" gorm:"column:payer_iban"`
+	CreditedIBAN   string         `json:"creditedIban,omitempty" gorm:"column:credited_iban"` // The virtual IBAN paid, if any
+	RemittanceInfo string         `json:"remittanceInfo,omitempty"`
+	Status         TransferStatus `json:"status"`
+	OrderID        *uuid.UUID     `json:"orderId,omitempty" gorm:"type:uuid;index"`
+	Reason         string         `json:"reason,omitempty"` // Why the transfer was not matched
+	ReviewedBy     *uuid.UUID     `json:"reviewedBy,omitempty" gorm:"type:uuid"`
+	ReviewNote     string         `json:"reviewNote,omitempty"`
+	ReviewedAt     *time.Time     `json:"reviewedAt,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+func (Transfer) TableName() string {
+	return "bank_transfers"
+}
+
+// Incoming is a credit read from a statement or notified by the provider, before it
+// is recorded
+type Incoming struct {
+	BankReference  string
+	Amount         int64 // In cents
+	Currency       string
+	BookedAt       time.Time
+	PayerName      string
+	PayerIBAN      string
+	CreditedIBAN   string
+	RemittanceInfo string
+}
+
+// LineRequest is a wallet credited by an order
+type LineRequest struct {
+	WalletID uuid.UUID `json:"walletId" binding:"required"`
+	Amount   int64     `json:"amount" binding:"required,min=100"` // In cents, minimum 1€
+}
+
+// CreateOrderRequest opens a top-up order paid by bank transfer
+type CreateOrderRequest struct {
+	PayerName  string        `json:"payerName" binding:"required,max=140"`
+	PayerEmail string        `json:"payerEmail" binding:"omitempty,email"`
+	Lines      []LineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// MatchRequest matches an unmatched transfer to a pending order by hand
+type MatchRequest struct {
+	OrderID uuid.UUID `json:"orderId" binding:"required"`
+	Note    string    `json:"note" binding:"max=500"`
+}
+
+// DismissRequest closes the review of an unmatched transfer without crediting anything
+type DismissRequest struct {
+	Note string `json:"note" binding:"required,max=500"` // e.g. "Returned to the payer"
+}
+
+// ProviderNotification is a transfer credited to a virtual IBAN, as notified by the
+// provider webhook
+type ProviderNotification struct {
+	ID             string    `json:"id" binding:"required"` // Unique transfer ID at the provider
+	IBAN           string    `json:"iban" binding:"required"`
+	Amount         int64     `json:"amount" binding:"required,min=1"` // In cents
+	Currency       string    `json:"currency" binding:"required,len=3"`
+	BookedAt       time.Time `json:"bookedAt"`
+	PayerName      string    `json:"payerName"`
+	PayerIBAN      string    `json:"payerIban"`
+	RemittanceInfo string    `json:"remittanceInfo"`
+}
+
+// Instructions tell the payer how to pay an order
+type Instructions struct {
+	AccountHolder string `json:"accountHolder"`
+	IBAN          string `json:"iban"` // The virtual IBAN of the order, or the collection account
+	BIC           string `json:"bic,omitempty"`
+	Reference     string `json:"reference"` // To quote in the structured reference of the transfer
+	Amount        int64  `json:"amount"`    // In cents
+	Currency      string `json:"currency"`
+	ExpiresAt     string `json:"expiresAt"`
+}
+
+// OrderResponse is an order with its payment instructions
+type OrderResponse struct {
+	Order        Order        `json:"order"`
+	Instructions Instructions `json:"instructions"`
+}
+
+// ImportResult sums up the import of a statement
+type ImportResult struct {
+	Credits   int        `json:"credits"`   // Credits read from the statement, debits are ignored
+	Duplicate int        `json:"duplicate"` // Already recorded by an earlier import
+	Matched   int        `json:"matched"`
+	Unmatched int        `json:"unmatched"` // Flagged for review
+	Transfers []Transfer `json:"transfers"` // The transfers recorded by the import
+}
+
+// OrderFilter filters the listed orders
+type OrderFilter struct {
+	Status OrderStatus
+	Offset int
+	Limit  int
+}
+
+// TransferFilter filters the listed transfers
+type TransferFilter struct {
+	Status TransferStatus
+	Offset int
+	Limit  int
+}
@@ -0,0 +1,83 @@
+package banktransfer
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// referenceAlphabet leaves out the letters payers mistake for digits
+const referenceAlphabet = "0123456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// referenceLength is the length of the reference without its RF prefix and check digits
+const referenceLength = 12
+
+// referencePattern finds creditor references in remittance information, once spaces
+// are removed
+var referencePattern = regexp.MustCompile(`RF[0-9]{2}[0-9A-Z]{1,21}`)
+
+// NewReference generates an ISO 11649 creditor reference, e.g. RF18539007547034, that
+// banks check for typing mistakes when the payer enters it
+func NewReference() (string, error) {
+	max := big.NewInt(int64(len(referenceAlphabet)))
+	var b strings.Builder
+	for i := 0; i < referenceLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate reference: %w", err)
+		}
+		b.WriteByte(referenceAlphabet[n.Int64()])
+	}
+	base := b.String()
+	return fmt.Sprintf("RF%02d%s", 98-mod97(base+"RF00"), base), nil
+}
+
+// ValidReference reports whether a creditor reference has valid check digits, spaces
+// and case aside
+func ValidReference(reference string) bool {
+	reference = normalize(reference)
+	if len(reference) < 5 || len(reference) > 25 || !strings.HasPrefix(reference, "RF") {
+		return false
+	}
+	return mod97(reference[4:]+reference[:4]) == 1
+}
+
+// FindReferences returns the creditor references that may be quoted in remittance
+// information, whether typed with spaces or not. Once spaces are removed a reference
+// runs into the text that follows it, so every valid prefix of a candidate is returned
+// for the caller to look up.
+func FindReferences(text string) []string {
+	var references []string
+	for _, candidate := range referencePattern.FindAllString(normalize(text), -1) {
+		for end := 5; end <= len(candidate); end++ {
+			if ValidReference(candidate[:end]) {
+				references = append(references, candidate[:end])
+			}
+		}
+	}
+	return references
+}
+
+// normalize uppercases a reference or remittance text and removes its spaces
+func normalize(text string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(text), ""))
+}
+
+// mod97 computes the ISO 7064 remainder of an alphanumeric string, letters counting
+// as 10 to 35
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return -1
+		}
+	}
+	return remainder
+}
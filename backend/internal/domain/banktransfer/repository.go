@@ -0,0 +1,242 @@
+package banktransfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WalletState is the festival and status of a wallet credited by an order
+type WalletState struct {
+	ID         uuid.UUID
+	FestivalID uuid.UUID
+	Status     string
+}
+
+type Repository interface {
+	// GetWallets returns the wallets with one of the IDs, whatever their festival
+	GetWallets(ctx context.Context, ids []uuid.UUID) ([]WalletState, error)
+
+	CreateOrder(ctx context.Context, order *Order) error
+	GetOrder(ctx context.Context, festivalID, id uuid.UUID) (*Order, error)
+	// FindOrder returns the order of the festival with one of the references, or with
+	// the virtual IBAN, a pending one first
+	FindOrder(ctx context.Context, festivalID uuid.UUID, references []string, iban string) (*Order, error)
+	// GetOrderByIBAN returns the order the virtual IBAN was opened for, in any festival
+	GetOrderByIBAN(ctx context.Context, iban string) (*Order, error)
+	ListOrders(ctx context.Context, festivalID uuid.UUID, filter OrderFilter) ([]Order, int64, error)
+	// UpdateOrder saves the status and lines of an order
+	UpdateOrder(ctx context.Context, order *Order) error
+
+	// CreateTransfer records a transfer unless the festival already has one with the same
+	// bank reference, and reports whether it was recorded
+	CreateTransfer(ctx context.Context, transfer *Transfer) (bool, error)
+	GetTransfer(ctx context.Context, festivalID, id uuid.UUID) (*Transfer, error)
+	ListTransfers(ctx context.Context, festivalID uuid.UUID, filter TransferFilter) ([]Transfer, int64, error)
+	UpdateTransfer(ctx context.Context, transfer *Transfer) error
+	// PayOrder marks a pending order paid by a transfer and the transfer matched, in one
+	// transaction. It returns ErrOrderNotPending when the order was paid or cancelled
+	// meanwhile.
+	PayOrder(ctx context.Context, order *Order, transfer *Transfer) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetWallets(ctx context.Context, ids []uuid.UUID) ([]WalletState, error) {
+	var wallets []WalletState
+	if len(ids) == 0 {
+		return wallets, nil
+	}
+	err := r.db.WithContext(ctx).
+		Raw("SELECT id, festival_id, status FROM wallets WHERE id IN (?)", ids).
+		Scan(&wallets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	return wallets, nil
+}
+
+func (r *repository) CreateOrder(ctx context.Context, order *Order) error {
+	if err := r.db.WithContext(ctx).Create(order).Error; err != nil {
+		return fmt.Errorf("failed to create top-up order: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetOrder(ctx context.Context, festivalID, id uuid.UUID) (*Order, error) {
+	var order Order
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND id = ?", festivalID, id).
+		First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get top-up order: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *repository) FindOrder(ctx context.Context, festivalID uuid.UUID, references []string, iban string) (*Order, error) {
+	if len(references) == 0 && iban == "" {
+		return nil, nil
+	}
+
+	query := r.db.WithContext(ctx).Model(&Order{}).Where("festival_id = ?", festivalID)
+	switch {
+	case len(references) > 0 && iban != "":
+		query = query.Where("(reference IN (?) OR virtual_iban = ?)", references, iban)
+	case iban != "":
+		query = query.Where("virtual_iban = ?", iban)
+	default:
+		query = query.Where("reference IN (?)", references)
+	}
+
+	// A pending order first, should a payer quote the references of several orders
+	var order Order
+	err := query.
+		Order("status = 'PENDING' DESC, created_at DESC").
+		First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find top-up order: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *repository) GetOrderByIBAN(ctx context.Context, iban string) (*Order, error) {
+	var order Order
+	err := r.db.WithContext(ctx).Where("virtual_iban = ?", iban).First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get top-up order: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *repository) ListOrders(ctx context.Context, festivalID uuid.UUID, filter OrderFilter) ([]Order, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Order{}).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count top-up orders: %w", err)
+	}
+
+	var orders []Order
+	err := query.Order("created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&orders).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list top-up orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+func (r *repository) UpdateOrder(ctx context.Context, order *Order) error {
+	err := r.db.WithContext(ctx).Model(order).
+		Select("status", "lines", "updated_at").
+		Updates(order).Error
+	if err != nil {
+		return fmt.Errorf("failed to update top-up order: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) CreateTransfer(ctx context.Context, transfer *Transfer) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "festival_id"}, {Name: "bank_reference"}},
+			DoNothing: true,
+		}).
+		Create(transfer)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create bank transfer: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) GetTransfer(ctx context.Context, festivalID, id uuid.UUID) (*Transfer, error) {
+	var transfer Transfer
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND id = ?", festivalID, id).
+		First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bank transfer: %w", err)
+	}
+	return &transfer, nil
+}
+
+func (r *repository) ListTransfers(ctx context.Context, festivalID uuid.UUID, filter TransferFilter) ([]Transfer, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Transfer{}).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count bank transfers: %w", err)
+	}
+
+	var transfers []Transfer
+	err := query.Order("booked_at DESC, created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&transfers).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list bank transfers: %w", err)
+	}
+	return transfers, total, nil
+}
+
+func (r *repository) UpdateTransfer(ctx context.Context, transfer *Transfer) error {
+	err := r.db.WithContext(ctx).Model(transfer).
+		Select("status", "order_id", "reason", "reviewed_by", "review_note", "reviewed_at", "updated_at").
+		Updates(transfer).Error
+	if err != nil {
+		return fmt.Errorf("failed to update bank transfer: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) PayOrder(ctx context.Context, order *Order, transfer *Transfer) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Order{}).
+			Where("id = ? AND status = ?", order.ID, OrderPending).
+			Updates(map[string]interface{}{
+				"status":      OrderPaid,
+				"transfer_id": transfer.ID,
+				"paid_at":     order.PaidAt,
+				"updated_at":  time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to pay top-up order: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderNotPending
+		}
+
+		err := tx.Model(transfer).
+			Select("status", "order_id", "reason", "reviewed_by", "review_note", "reviewed_at", "updated_at").
+			Updates(transfer).Error
+		if err != nil {
+			return fmt.Errorf("failed to match bank transfer: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package banktransfer
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) GetWallets(ctx context.Context, ids []uuid.UUID) ([]WalletState, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]WalletState), args.Error(1)
+}
+
+func (m *MockRepository) CreateOrder(ctx context.Context, order *Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetOrder(ctx context.Context, festivalID, id uuid.UUID) (*Order, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Order), args.Error(1)
+}
+
+func (m *MockRepository) FindOrder(ctx context.Context, festivalID uuid.UUID, references []string, iban string) (*Order, error) {
+	args := m.Called(ctx, festivalID, references, iban)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Order), args.Error(1)
+}
+
+func (m *MockRepository) GetOrderByIBAN(ctx context.Context, iban string) (*Order, error) {
+	args := m.Called(ctx, iban)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Order), args.Error(1)
+}
+
+func (m *MockRepository) ListOrders(ctx context.Context, festivalID uuid.UUID, filter OrderFilter) ([]Order, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Order), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) UpdateOrder(ctx context.Context, order *Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateTransfer(ctx context.Context, transfer *Transfer) (bool, error) {
+	args := m.Called(ctx, transfer)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetTransfer(ctx context.Context, festivalID, id uuid.UUID) (*Transfer, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Transfer), args.Error(1)
+}
+
+func (m *MockRepository) ListTransfers(ctx context.Context, festivalID uuid.UUID, filter TransferFilter) ([]Transfer, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Transfer), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) UpdateTransfer(ctx context.Context, transfer *Transfer) error {
+	args := m.Called(ctx, transfer)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PayOrder(ctx context.Context, order *Order, transfer *Transfer) error {
+	args := m.Called(ctx, order, transfer)
+	return args.Error(0)
+}
@@ -0,0 +1,538 @@
+package banktransfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/rs/zerolog/log"
+)
+
+// WalletCrediter credits the wallets of paid orders; satisfied by wallet.Service
+type WalletCrediter interface {
+	TopUpFromTransfer(ctx context.Context, walletID, transactionID uuid.UUID, amount int64, reference string) (*wallet.Transaction, error)
+}
+
+// IBANProvider opens a virtual IBAN routed to the collection account for each order, so
+// that its transfer is matched even when the payer leaves the reference out; satisfied
+// by payment.VirtualIBANClient
+type IBANProvider interface {
+	OpenIBAN(ctx context.Context, reference, label string) (string, error)
+	CloseIBAN(ctx context.Context, iban string) error
+}
+
+// Config is the collection account the transfers are paid to
+type Config struct {
+	AccountHolder string
+	IBAN          string
+	BIC           string
+	Currency      string        // Of the orders and of the wallets, e.g. EUR
+	OrderValidity time.Duration // How long an order waits for its transfer
+}
+
+// DefaultConfig returns the default configuration, without collection account
+func DefaultConfig() Config {
+	return Config{
+		Currency:      "EUR",
+		OrderValidity: 14 * 24 * time.Hour,
+	}
+}
+
+// Service tops up wallets by bank transfer. Incoming transfers, imported from bank
+// statements or notified by the virtual IBAN provider, are matched to the pending
+// top-up orders by reference or virtual IBAN; the others are flagged for a manual
+// review.
+type Service struct {
+	repo    Repository
+	wallets WalletCrediter
+	ibans   IBANProvider
+	config  Config
+	now     func() time.Time
+}
+
+// NewService creates the bank transfer service
+func NewService(repo Repository, wallets WalletCrediter, config Config) *Service {
+	return &Service{
+		repo:    repo,
+		wallets: wallets,
+		config:  config,
+		now:     time.Now,
+	}
+}
+
+// SetIBANProvider opens a virtual IBAN for each new order
+func (s *Service) SetIBANProvider(ibans IBANProvider) {
+	s.ibans = ibans
+}
+
+// CreateOrder opens a top-up order crediting active wallets of the festival once its
+// transfer is received, and returns the payment instructions to send to the payer
+func (s *Service) CreateOrder(ctx context.Context, festivalID uuid.UUID, req CreateOrderRequest, createdBy *uuid.UUID) (*OrderResponse, error) {
+	if s.config.IBAN == "" && s.ibans == nil {
+		return nil, ErrCollectionAccount
+	}
+	if len(req.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+	if len(req.Lines) > MaxLines {
+		return nil, ErrTooManyLines
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.Lines))
+	seen := make(map[uuid.UUID]bool, len(req.Lines))
+	for _, line := range req.Lines {
+		if seen[line.WalletID] {
+			return nil, ErrDuplicateWallet
+		}
+		seen[line.WalletID] = true
+		ids = append(ids, line.WalletID)
+	}
+	wallets, err := s.repo.GetWallets(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[uuid.UUID]WalletState, len(wallets))
+	for _, w := range wallets {
+		states[w.ID] = w
+	}
+
+	now := s.now()
+	order := &Order{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		PayerName:  strings.TrimSpace(req.PayerName),
+		PayerEmail: strings.TrimSpace(req.PayerEmail),
+		Currency:   s.config.Currency,
+		Lines:      make([]Line, 0, len(req.Lines)),
+		Status:     OrderPending,
+		ExpiresAt:  now.Add(s.config.OrderValidity),
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	for _, line := range req.Lines {
+		state, ok := states[line.WalletID]
+		if !ok || state.FestivalID != festivalID {
+			return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, line.WalletID)
+		}
+		if state.Status != string(wallet.WalletStatusActive) {
+			return nil, fmt.Errorf("%w: %s", ErrWalletNotActive, line.WalletID)
+		}
+		order.Amount += line.Amount
+		order.Lines = append(order.Lines, Line{
+			WalletID:      line.WalletID,
+			Amount:        line.Amount,
+			TransactionID: uuid.New(),
+		})
+	}
+
+	if order.Reference, err = NewReference(); err != nil {
+		return nil, err
+	}
+	if s.ibans != nil {
+		iban, err := s.ibans.OpenIBAN(ctx, order.Reference, order.PayerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open virtual IBAN: %w", err)
+		}
+		iban = normalize(iban)
+		order.VirtualIBAN = &iban
+	}
+
+	if err := s.repo.CreateOrder(ctx, order); err != nil {
+		s.closeIBAN(ctx, order)
+		return nil, err
+	}
+	return s.orderResponse(order), nil
+}
+
+// GetOrder returns an order with its payment instructions
+func (s *Service) GetOrder(ctx context.Context, festivalID, id uuid.UUID) (*OrderResponse, error) {
+	order, err := s.getOrder(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.orderResponse(order), nil
+}
+
+// ListOrders lists the orders of the festival, latest first
+func (s *Service) ListOrders(ctx context.Context, festivalID uuid.UUID, filter OrderFilter) ([]Order, int64, error) {
+	return s.repo.ListOrders(ctx, festivalID, filter)
+}
+
+// CancelOrder cancels a pending order: its transfer, should it still come, is flagged
+// for review instead of crediting the wallets
+func (s *Service) CancelOrder(ctx context.Context, festivalID, id uuid.UUID) (*Order, error) {
+	order, err := s.getOrder(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != OrderPending {
+		return nil, ErrOrderNotPending
+	}
+
+	order.Status = OrderCancelled
+	order.UpdatedAt = s.now()
+	if err := s.repo.UpdateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	s.closeIBAN(ctx, order)
+	return order, nil
+}
+
+// RetryCredit credits again the wallets of a paid order that could not be credited,
+// e.g. a wallet frozen when the transfer was received. The wallets already credited
+// are not credited twice.
+func (s *Service) RetryCredit(ctx context.Context, festivalID, id uuid.UUID) (*Order, error) {
+	order, err := s.getOrder(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != OrderPaid {
+		return nil, ErrOrderNotPaid
+	}
+	if err := s.credit(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ImportStatement records the credits of a camt.053 or MT940 statement of the
+// collection account and matches them to the pending orders. The credits of an earlier
+// import are skipped, so overlapping statements can be imported.
+func (s *Service) ImportStatement(ctx context.Context, festivalID uuid.UUID, data []byte) (*ImportResult, error) {
+	source, credits, err := ParseStatement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Credits: len(credits), Transfers: []Transfer{}}
+	for _, incoming := range credits {
+		transfer, recorded, err := s.receive(ctx, festivalID, source, incoming)
+		if err != nil {
+			return nil, err
+		}
+		if !recorded {
+			result.Duplicate++
+			continue
+		}
+		if transfer.Status == TransferMatched {
+			result.Matched++
+		} else {
+			result.Unmatched++
+		}
+		result.Transfers = append(result.Transfers, *transfer)
+	}
+
+	log.Info().
+		Str("festival_id", festivalID.String()).
+		Str("source", string(source)).
+		Int("matched", result.Matched).
+		Int("unmatched", result.Unmatched).
+		Int("duplicate", result.Duplicate).
+		Msg("Imported bank statement")
+	return result, nil
+}
+
+// HandleNotification records a transfer to a virtual IBAN notified by the provider and
+// matches it to the order the IBAN was opened for. A notification received again is
+// not recorded twice.
+func (s *Service) HandleNotification(ctx context.Context, n ProviderNotification) (*Transfer, error) {
+	order, err := s.repo.GetOrderByIBAN(ctx, normalize(n.IBAN))
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	bookedAt := n.BookedAt
+	if bookedAt.IsZero() {
+		bookedAt = s.now()
+	}
+	transfer, _, err := s.receive(ctx, order.FestivalID, SourceProvider, Incoming{
+		BankReference:  "provider:" + n.ID,
+		Amount:         n.Amount,
+		Currency:       strings.ToUpper(n.Currency),
+		BookedAt:       bookedAt,
+		PayerName:      strings.TrimSpace(n.PayerName),
+		PayerIBAN:      normalize(n.PayerIBAN),
+		CreditedIBAN:   normalize(n.IBAN),
+		RemittanceInfo: strings.TrimSpace(n.RemittanceInfo),
+	})
+	return transfer, err
+}
+
+// ListTransfers lists the transfers of the festival, latest booked first
+func (s *Service) ListTransfers(ctx context.Context, festivalID uuid.UUID, filter TransferFilter) ([]Transfer, int64, error) {
+	return s.repo.ListTransfers(ctx, festivalID, filter)
+}
+
+// GetTransfer returns a transfer
+func (s *Service) GetTransfer(ctx context.Context, festivalID, id uuid.UUID) (*Transfer, error) {
+	transfer, err := s.repo.GetTransfer(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// MatchTransfer matches an unmatched transfer to a pending order by hand, e.g. when the
+// payer mistyped the reference, and credits its wallets. The amounts must be equal; an
+// expired order can still be paid.
+func (s *Service) MatchTransfer(ctx context.Context, festivalID, id uuid.UUID, req MatchRequest, reviewedBy *uuid.UUID) (*Transfer, error) {
+	transfer, err := s.getUnmatched(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.getOrder(ctx, festivalID, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case order.Status != OrderPending:
+		return nil, ErrOrderNotPending
+	case transfer.Currency != order.Currency:
+		return nil, ErrCurrencyMismatch
+	case transfer.Amount != order.Amount:
+		return nil, ErrAmountMismatch
+	}
+
+	now := s.now()
+	transfer.ReviewedBy = reviewedBy
+	transfer.ReviewNote = strings.TrimSpace(req.Note)
+	transfer.ReviewedAt = &now
+	if err := s.pay(ctx, order, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// DismissTransfer closes the review of an unmatched transfer without crediting
+// anything, e.g. once it was returned to the payer
+func (s *Service) DismissTransfer(ctx context.Context, festivalID, id uuid.UUID, req DismissRequest, reviewedBy *uuid.UUID) (*Transfer, error) {
+	transfer, err := s.getUnmatched(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	transfer.Status = TransferDismissed
+	transfer.ReviewedBy = reviewedBy
+	transfer.ReviewNote = strings.TrimSpace(req.Note)
+	transfer.ReviewedAt = &now
+	transfer.UpdatedAt = now
+	if err := s.repo.UpdateTransfer(ctx, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// receive records an incoming transfer, unless the festival already has it, and pays
+// the order it matches. A transfer matching no payable order is left unmatched with
+// the reason.
+func (s *Service) receive(ctx context.Context, festivalID uuid.UUID, source TransferSource, in Incoming) (*Transfer, bool, error) {
+	now := s.now()
+	transfer := &Transfer{
+		ID:             uuid.New(),
+		FestivalID:     festivalID,
+		Source:         source,
+		BankReference:  in.BankReference,
+		Amount:         in.Amount,
+		Currency:       in.Currency,
+		BookedAt:       in.BookedAt,
+		PayerName:      in.PayerName,
+		PayerIBAN:      in.PayerIBAN,
+		CreditedIBAN:   in.CreditedIBAN,
+		RemittanceInfo: in.RemittanceInfo,
+		Status:         TransferUnmatched,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	order, reason, err := s.match(ctx, festivalID, in)
+	if err != nil {
+		return nil, false, err
+	}
+	transfer.Reason = reason
+	if order != nil {
+		transfer.OrderID = &order.ID
+	}
+
+	// Recorded unmatched first, so that a statement imported twice never pays twice
+	recorded, err := s.repo.CreateTransfer(ctx, transfer)
+	if err != nil || !recorded {
+		return transfer, recorded, err
+	}
+	if reason != "" {
+		log.Warn().
+			Str("festival_id", festivalID.String()).
+			Str("transfer_id", transfer.ID.String()).
+			Str("reason", reason).
+			Msg("Bank transfer flagged for review")
+		return transfer, true, nil
+	}
+
+	if err := s.pay(ctx, order, transfer); err != nil {
+		if !errors.Is(err, ErrOrderNotPending) {
+			return nil, true, err
+		}
+		// Paid or cancelled since it was matched
+		transfer.Reason = "order is no longer pending"
+		transfer.UpdatedAt = s.now()
+		if err := s.repo.UpdateTransfer(ctx, transfer); err != nil {
+			return nil, true, err
+		}
+	}
+	return transfer, true, nil
+}
+
+// match finds the order an incoming transfer pays, by virtual IBAN or by the reference
+// quoted by the payer. It returns why the transfer cannot pay it, or an empty reason.
+func (s *Service) match(ctx context.Context, festivalID uuid.UUID, in Incoming) (*Order, string, error) {
+	references := FindReferences(in.RemittanceInfo)
+	order, err := s.repo.FindOrder(ctx, festivalID, references, in.CreditedIBAN)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case order == nil && len(references) == 0:
+		return nil, "no order reference in the remittance information", nil
+	case order == nil:
+		return nil, "no order with the reference " + references[0], nil
+	case order.Status == OrderPaid:
+		return order, "order already paid", nil
+	case order.Status == OrderCancelled:
+		return order, "order was cancelled", nil
+	case in.BookedAt.After(order.ExpiresAt):
+		return order, "order expired on " + order.ExpiresAt.Format("2006-01-02"), nil
+	case in.Currency != order.Currency:
+		return order, fmt.Sprintf("transfer in %s, order in %s", in.Currency, order.Currency), nil
+	case in.Amount != order.Amount:
+		return order, fmt.Sprintf("transfer of %s, order of %s", formatAmount(in.Amount, in.Currency), formatAmount(order.Amount, order.Currency)), nil
+	}
+	return order, "", nil
+}
+
+// pay marks an order paid by a transfer, credits its wallets and closes its virtual IBAN
+func (s *Service) pay(ctx context.Context, order *Order, transfer *Transfer) error {
+	now := s.now()
+	transfer.Status = TransferMatched
+	transfer.OrderID = &order.ID
+	transfer.Reason = ""
+	transfer.UpdatedAt = now
+	order.PaidAt = &now
+	if err := s.repo.PayOrder(ctx, order, transfer); err != nil {
+		transfer.Status = TransferUnmatched
+		return err
+	}
+	order.Status = OrderPaid
+	order.TransferID = &transfer.ID
+
+	log.Info().
+		Str("order_id", order.ID.String()).
+		Str("transfer_id", transfer.ID.String()).
+		Int64("amount", order.Amount).
+		Msg("Bank transfer matched to top-up order")
+
+	s.closeIBAN(ctx, order)
+	return s.credit(ctx, order)
+}
+
+// credit credits the wallets of a paid order not credited yet. A wallet that cannot be
+// credited keeps the error for a retry without stopping the others.
+func (s *Service) credit(ctx context.Context, order *Order) error {
+	failed := 0
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		if line.CreditedAt != nil {
+			continue
+		}
+
+		_, err := s.wallets.TopUpFromTransfer(ctx, line.WalletID, line.TransactionID, line.Amount, order.Reference)
+		if err != nil && !errors.Is(err, wallet.ErrAdjustmentApplied) {
+			line.Error = err.Error()
+			failed++
+			continue
+		}
+		creditedAt := s.now()
+		line.CreditedAt = &creditedAt
+		line.Error = ""
+	}
+
+	order.UpdatedAt = s.now()
+	if err := s.repo.UpdateOrder(ctx, order); err != nil {
+		return err
+	}
+	if failed > 0 {
+		log.Warn().
+			Str("order_id", order.ID.String()).
+			Int("failed", failed).
+			Msg("Some wallets of a paid top-up order could not be credited")
+	}
+	return nil
+}
+
+// closeIBAN closes the virtual IBAN of an order no longer waiting for a transfer; later
+// transfers to it are refused by the bank
+func (s *Service) closeIBAN(ctx context.Context, order *Order) {
+	if s.ibans == nil || order.VirtualIBAN == nil {
+		return
+	}
+	if err := s.ibans.CloseIBAN(ctx, *order.VirtualIBAN); err != nil {
+		log.Warn().Err(err).Str("order_id", order.ID.String()).Msg("Failed to close virtual IBAN")
+	}
+}
+
+func (s *Service) getOrder(ctx context.Context, festivalID, id uuid.UUID) (*Order, error) {
+	order, err := s.repo.GetOrder(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *Service) getUnmatched(ctx context.Context, festivalID, id uuid.UUID) (*Transfer, error) {
+	transfer, err := s.GetTransfer(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != TransferUnmatched {
+		return nil, ErrTransferReviewed
+	}
+	return transfer, nil
+}
+
+// orderResponse adds the payment instructions to an order
+func (s *Service) orderResponse(order *Order) *OrderResponse {
+	iban := s.config.IBAN
+	if order.VirtualIBAN != nil {
+		iban = *order.VirtualIBAN
+	}
+	return &OrderResponse{
+		Order: *order,
+		Instructions: Instructions{
+			AccountHolder: s.config.AccountHolder,
+			IBAN:          iban,
+			BIC:           s.config.BIC,
+			Reference:     order.Reference,
+			Amount:        order.Amount,
+			Currency:      order.Currency,
+			ExpiresAt:     order.ExpiresAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func formatAmount(cents int64, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", cents/100, cents%100, currency)
+}
@@ -0,0 +1,276 @@
+package banktransfer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCrediter struct {
+	failing  map[uuid.UUID]bool
+	credited map[uuid.UUID]int64
+	applied  map[uuid.UUID]bool
+}
+
+func (c *fakeCrediter) TopUpFromTransfer(ctx context.Context, walletID, transactionID uuid.UUID, amount int64, reference string) (*wallet.Transaction, error) {
+	if c.failing[walletID] {
+		return nil, errors.New("wallet is not active")
+	}
+	if c.applied[transactionID] {
+		return nil, wallet.ErrAdjustmentApplied
+	}
+	c.applied[transactionID] = true
+	c.credited[walletID] += amount
+	return &wallet.Transaction{ID: transactionID}, nil
+}
+
+func newTestService() (*Service, *MockRepository, *fakeCrediter, uuid.UUID, []uuid.UUID) {
+	festivalID := uuid.New()
+	walletIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	mockRepo := NewMockRepository()
+	crediter := &fakeCrediter{failing: map[uuid.UUID]bool{}, credited: map[uuid.UUID]int64{}, applied: map[uuid.UUID]bool{}}
+
+	config := DefaultConfig()
+	config.AccountHolder = "Festival SA"
+	config.IBAN = "BE68539007547034"
+	service := NewService(mockRepo, crediter, config)
+	service.now = func() time.Time { return time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC) }
+	return service, mockRepo, crediter, festivalID, walletIDs
+}
+
+// activeWallets returns the states of active wallets of the festival
+func activeWallets(festivalID uuid.UUID, ids []uuid.UUID) []WalletState {
+	states := make([]WalletState, 0, len(ids))
+	for _, id := range ids {
+		states = append(states, WalletState{ID: id, FestivalID: festivalID, Status: "ACTIVE"})
+	}
+	return states
+}
+
+// createOrder opens an order crediting both wallets. The repository returns the order
+// from then on, by ID or by its reference.
+func createOrder(t *testing.T, service *Service, mockRepo *MockRepository, festivalID uuid.UUID, walletIDs []uuid.UUID) *Order {
+	stored := &Order{}
+	mockRepo.On("GetWallets", mock.Anything, walletIDs).Return(activeWallets(festivalID, walletIDs), nil).Once()
+	mockRepo.On("CreateOrder", mock.Anything, mock.AnythingOfType("*banktransfer.Order")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Order) }).
+		Return(nil).Once()
+
+	_, err := service.CreateOrder(context.Background(), festivalID, CreateOrderRequest{
+		PayerName: "ACME Corp",
+		Lines: []LineRequest{
+			{WalletID: walletIDs[0], Amount: 5000},
+			{WalletID: walletIDs[1], Amount: 7500},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	mockRepo.On("GetOrder", mock.Anything, festivalID, stored.ID).Return(stored, nil).Maybe()
+	mockRepo.On("FindOrder", mock.Anything, festivalID, mock.MatchedBy(func(references []string) bool {
+		return slices.Contains(references, stored.Reference)
+	}), mock.Anything).Return(stored, nil)
+	return stored
+}
+
+// expectTransfers records the next count transfers and returns them by bank reference
+func expectTransfers(mockRepo *MockRepository, count int) map[string]*Transfer {
+	transfers := make(map[string]*Transfer)
+	mockRepo.On("CreateTransfer", mock.Anything, mock.AnythingOfType("*banktransfer.Transfer")).
+		Run(func(args mock.Arguments) {
+			transfer := args.Get(1).(*Transfer)
+			transfers[transfer.BankReference] = transfer
+		}).
+		Return(true, nil).Times(count)
+	return transfers
+}
+
+// paidBy matches the transfer paying an order
+func paidBy(bankReference string) interface{} {
+	return mock.MatchedBy(func(transfer *Transfer) bool {
+		return transfer.BankReference == bankReference && transfer.Status == TransferMatched
+	})
+}
+
+func TestService_CreateOrder(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, _, festivalID, walletIDs := newTestService()
+
+	mockRepo.On("GetWallets", mock.Anything, mock.Anything).Return(activeWallets(festivalID, walletIDs), nil).Twice()
+	mockRepo.On("CreateOrder", mock.Anything, mock.AnythingOfType("*banktransfer.Order")).Return(nil).Once()
+	resp, err := service.CreateOrder(ctx, festivalID, CreateOrderRequest{
+		PayerName: " ACME Corp ",
+		Lines:     []LineRequest{{WalletID: walletIDs[0], Amount: 5000}, {WalletID: walletIDs[1], Amount: 7500}},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12500), resp.Order.Amount)
+	assert.Equal(t, "ACME Corp", resp.Order.PayerName)
+	assert.True(t, ValidReference(resp.Order.Reference))
+	assert.Equal(t, "BE68539007547034", resp.Instructions.IBAN)
+	assert.Equal(t, resp.Order.Reference, resp.Instructions.Reference)
+	assert.Equal(t, "2026-07-15T10:00:00Z", resp.Instructions.ExpiresAt)
+
+	_, err = service.CreateOrder(ctx, festivalID, CreateOrderRequest{
+		PayerName: "ACME Corp",
+		Lines:     []LineRequest{{WalletID: walletIDs[0], Amount: 5000}, {WalletID: walletIDs[0], Amount: 5000}},
+	}, nil)
+	assert.ErrorIs(t, err, ErrDuplicateWallet)
+
+	_, err = service.CreateOrder(ctx, uuid.New(), CreateOrderRequest{
+		PayerName: "ACME Corp",
+		Lines:     []LineRequest{{WalletID: walletIDs[0], Amount: 5000}},
+	}, nil)
+	assert.ErrorIs(t, err, ErrWalletNotFound, "wallet of another festival")
+
+	frozen := activeWallets(festivalID, walletIDs[:1])
+	frozen[0].Status = "FROZEN"
+	mockRepo.On("GetWallets", mock.Anything, mock.Anything).Return(frozen, nil).Once()
+	_, err = service.CreateOrder(ctx, festivalID, CreateOrderRequest{
+		PayerName: "ACME Corp",
+		Lines:     []LineRequest{{WalletID: walletIDs[0], Amount: 5000}},
+	}, nil)
+	assert.ErrorIs(t, err, ErrWalletNotActive)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ImportStatement(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, crediter, festivalID, walletIDs := newTestService()
+	order := createOrder(t, service, mockRepo, festivalID, walletIDs)
+
+	statement := `:20:STATEMENT
+:25:BE68539007547034
+:60F:C260630EUR0,00
+:61:2607020702C125,00NTRFNONREF//REF1
+:86:Group booking ` + order.Reference[:8] + " " + order.Reference[8:] + `
+:61:2607020702C40,00NTRFNONREF//REF2
+:86:Top-up for my wallet
+:62F:C260702EUR165,00
+-`
+	mockRepo.On("FindOrder", mock.Anything, festivalID, mock.MatchedBy(func(references []string) bool {
+		return len(references) == 0
+	}), mock.Anything).Return(nil, nil)
+	transfers := expectTransfers(mockRepo, 2)
+	mockRepo.On("PayOrder", mock.Anything, order, paidBy("REF1")).Return(nil).Once()
+	mockRepo.On("UpdateOrder", mock.Anything, order).Return(nil).Once()
+
+	result, err := service.ImportStatement(ctx, festivalID, []byte(statement))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Credits)
+	assert.Equal(t, 1, result.Matched)
+	assert.Equal(t, 1, result.Unmatched)
+
+	assert.Equal(t, OrderPaid, order.Status)
+	assert.True(t, order.Credited())
+	assert.Equal(t, int64(5000), crediter.credited[walletIDs[0]])
+	assert.Equal(t, int64(7500), crediter.credited[walletIDs[1]])
+
+	unmatched := transfers["REF2"]
+	require.NotNil(t, unmatched)
+	assert.Equal(t, TransferUnmatched, unmatched.Status)
+	assert.Equal(t, "no order reference in the remittance information", unmatched.Reason)
+
+	// The statement imported again records and credits nothing twice
+	mockRepo.On("CreateTransfer", mock.Anything, mock.AnythingOfType("*banktransfer.Transfer")).Return(false, nil).Twice()
+	result, err = service.ImportStatement(ctx, festivalID, []byte(statement))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Duplicate)
+	assert.Equal(t, int64(5000), crediter.credited[walletIDs[0]])
+	mockRepo.AssertNumberOfCalls(t, "PayOrder", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_MatchFlagsMismatches(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, crediter, festivalID, walletIDs := newTestService()
+	order := createOrder(t, service, mockRepo, festivalID, walletIDs)
+	expectTransfers(mockRepo, 2)
+
+	short, recorded, err := service.receive(ctx, festivalID, SourceMT940, Incoming{
+		BankReference:  "SHORT",
+		Amount:         10000,
+		Currency:       "EUR",
+		BookedAt:       time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC),
+		RemittanceInfo: order.Reference,
+	})
+	require.NoError(t, err)
+	assert.True(t, recorded)
+	assert.Equal(t, TransferUnmatched, short.Status)
+	assert.Equal(t, "transfer of 100.00 EUR, order of 125.00 EUR", short.Reason)
+	assert.Equal(t, OrderPending, order.Status)
+
+	transfer, _, err := service.receive(ctx, festivalID, SourceMT940, Incoming{
+		BankReference:  "LATE",
+		Amount:         12500,
+		Currency:       "EUR",
+		BookedAt:       time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+		RemittanceInfo: order.Reference,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "order expired on 2026-07-15", transfer.Reason)
+	mockRepo.AssertNotCalled(t, "PayOrder", mock.Anything, mock.Anything, mock.Anything)
+
+	// The organizer accepts the late transfer
+	mockRepo.On("GetTransfer", mock.Anything, festivalID, transfer.ID).Return(transfer, nil)
+	mockRepo.On("PayOrder", mock.Anything, order, paidBy("LATE")).Return(nil).Once()
+	mockRepo.On("UpdateOrder", mock.Anything, order).Return(nil).Once()
+	matched, err := service.MatchTransfer(ctx, festivalID, transfer.ID, MatchRequest{OrderID: order.ID, Note: "Late payment"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, TransferMatched, matched.Status)
+	assert.Equal(t, "Late payment", matched.ReviewNote)
+	assert.Equal(t, OrderPaid, order.Status)
+	assert.Equal(t, int64(7500), crediter.credited[walletIDs[1]])
+
+	_, err = service.MatchTransfer(ctx, festivalID, transfer.ID, MatchRequest{OrderID: order.ID}, nil)
+	assert.ErrorIs(t, err, ErrTransferReviewed)
+
+	mockRepo.On("GetTransfer", mock.Anything, festivalID, short.ID).Return(short, nil)
+	_, err = service.MatchTransfer(ctx, festivalID, short.ID, MatchRequest{OrderID: order.ID}, nil)
+	assert.ErrorIs(t, err, ErrOrderNotPending)
+	mockRepo.On("UpdateTransfer", mock.Anything, mock.MatchedBy(func(transfer *Transfer) bool {
+		return transfer.ID == short.ID && transfer.Status == TransferDismissed
+	})).Return(nil).Once()
+	dismissed, err := service.DismissTransfer(ctx, festivalID, short.ID, DismissRequest{Note: "Returned to the payer"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, TransferDismissed, dismissed.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RetryCredit(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, crediter, festivalID, walletIDs := newTestService()
+	order := createOrder(t, service, mockRepo, festivalID, walletIDs)
+	expectTransfers(mockRepo, 1)
+	mockRepo.On("PayOrder", mock.Anything, order, paidBy("REF1")).Return(nil).Once()
+	mockRepo.On("UpdateOrder", mock.Anything, order).Return(nil).Twice()
+
+	crediter.failing[walletIDs[1]] = true
+	_, _, err := service.receive(ctx, festivalID, SourceCAMT053, Incoming{
+		BankReference:  "REF1",
+		Amount:         12500,
+		Currency:       "EUR",
+		BookedAt:       time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC),
+		RemittanceInfo: order.Reference,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, OrderPaid, order.Status)
+	assert.False(t, order.Credited())
+	assert.Equal(t, "wallet is not active", order.Lines[1].Error)
+
+	crediter.failing[walletIDs[1]] = false
+	retried, err := service.RetryCredit(ctx, festivalID, order.ID)
+	require.NoError(t, err)
+	assert.True(t, retried.Credited())
+	assert.Empty(t, retried.Lines[1].Error)
+	assert.Equal(t, int64(5000), crediter.credited[walletIDs[0]], "credited wallets not credited twice")
+	assert.Equal(t, int64(7500), crediter.credited[walletIDs[1]])
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,391 @@
+package banktransfer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseStatement reads the credits of a camt.053 or MT940 bank statement. Debits,
+// reversals and entries not booked yet are left out. Entries without a bank reference
+// get a fingerprint instead, so that importing a statement again records nothing twice.
+func ParseStatement(data []byte) (TransferSource, []Incoming, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	var source TransferSource
+	var credits []Incoming
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("<")):
+		source = SourceCAMT053
+		credits, err = parseCAMT053(data)
+	case bytes.Contains(data, []byte(":61:")) || bytes.Contains(data, []byte(":20:")):
+		source = SourceMT940
+		credits, err = parseMT940(string(data))
+	default:
+		return "", nil, ErrInvalidStatement
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	seen := make(map[string]int)
+	for i := range credits {
+		if credits[i].BankReference == "" {
+			fingerprint := credits[i].fingerprint()
+			seen[fingerprint]++
+			credits[i].BankReference = fmt.Sprintf("%s#%d", fingerprint, seen[fingerprint])
+		}
+	}
+	return source, credits, nil
+}
+
+// fingerprint identifies a credit without bank reference by its content
+func (i Incoming) fingerprint() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		i.BookedAt.Format("2006-01-02"),
+		strconv.FormatInt(i.Amount, 10),
+		i.Currency,
+		i.PayerIBAN,
+		i.RemittanceInfo,
+	}, "|")))
+	return "fp:" + hex.EncodeToString(sum[:12])
+}
+
+// camt.053 statements, whatever the version of the schema: elements are matched by
+// their local name
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	IBAN    string      `xml:"Acct>Id>IBAN"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtStatus struct {
+	Value string `xml:",chardata"` // Up to camt.053.001.03
+	Code  string `xml:"Cd"`        // From camt.053.001.04
+}
+
+type camtEntry struct {
+	Reference       string            `xml:"NtryRef"`
+	Amount          camtAmount        `xml:"Amt"`
+	Indicator       string            `xml:"CdtDbtInd"`
+	Reversal        bool              `xml:"RvslInd"`
+	Status          camtStatus        `xml:"Sts"`
+	BookingDate     string            `xml:"BookgDt>Dt"`
+	BookingDateTime string            `xml:"BookgDt>DtTm"`
+	ServicerRef     string            `xml:"AcctSvcrRef"`
+	Transactions    []camtTransaction `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTransaction struct {
+	Amount       camtAmount `xml:"Amt"`
+	TxAmount     camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	ServicerRef  string     `xml:"Refs>AcctSvcrRef"`
+	DebtorName   string     `xml:"RltdPties>Dbtr>Nm"`
+	DebtorParty  string     `xml:"RltdPties>Dbtr>Pty>Nm"`
+	DebtorIBAN   string     `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+	CreditorIBAN string     `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+	Unstructured []string   `xml:"RmtInf>Ustrd"`
+	Structured   []string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+func parseCAMT053(data []byte) ([]Incoming, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, ErrInvalidStatement
+	}
+
+	var credits []Incoming
+	for _, statement := range doc.Statements {
+		for _, entry := range statement.Entries {
+			status := strings.TrimSpace(entry.Status.Value)
+			if entry.Status.Code != "" {
+				status = entry.Status.Code
+			}
+			if entry.Indicator != "CRDT" || entry.Reversal || (status != "" && status != "BOOK") {
+				continue
+			}
+
+			bookedAt, err := camtDate(entry.BookingDate, entry.BookingDateTime)
+			if err != nil {
+				return nil, err
+			}
+
+			// A batch booking holds several transfers, each with its own amount
+			transactions := entry.Transactions
+			if len(transactions) == 0 {
+				transactions = []camtTransaction{{}}
+			}
+			for n, tx := range transactions {
+				amount := entry.Amount
+				if len(transactions) > 1 {
+					amount = tx.Amount
+					if amount.Value == "" {
+						amount = tx.TxAmount
+					}
+				}
+				cents, err := parseAmount(amount.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				reference := tx.ServicerRef
+				if reference == "" {
+					reference = entry.ServicerRef
+					if reference == "" {
+						reference = entry.Reference
+					}
+					if reference != "" && len(transactions) > 1 {
+						reference = fmt.Sprintf("%s/%d", reference, n+1)
+					}
+				}
+				payer := tx.DebtorName
+				if payer == "" {
+					payer = tx.DebtorParty
+				}
+				credited := tx.CreditorIBAN
+				if credited == "" {
+					credited = statement.IBAN
+				}
+
+				credits = append(credits, Incoming{
+					BankReference:  strings.TrimSpace(reference),
+					Amount:         cents,
+					Currency:       amount.Currency,
+					BookedAt:       bookedAt,
+					PayerName:      strings.TrimSpace(payer),
+					PayerIBAN:      normalize(tx.DebtorIBAN),
+					CreditedIBAN:   normalize(credited),
+					RemittanceInfo: strings.TrimSpace(strings.Join(append(tx.Structured, tx.Unstructured...), " ")),
+				})
+			}
+		}
+	}
+	return credits, nil
+}
+
+func camtDate(date, dateTime string) (time.Time, error) {
+	if dateTime != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+			if t, err := time.Parse(layout, strings.TrimSpace(dateTime)); err == nil {
+				return t, nil
+			}
+		}
+	}
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid booking date %q", ErrInvalidStatement, date+dateTime)
+	}
+	return t, nil
+}
+
+// mt940Line matches the :61: statement line: value date, optional entry date, debit or
+// credit mark, optional funds code, amount, transaction type, customer reference, bank
+// reference and supplementary details
+var mt940Line = regexp.MustCompile(`(?s)^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d{0,2})[NSF][A-Z0-9]{3}([^/\n]*)(?://([^\n]*))?`)
+
+// mt940Subfield matches the ?NN subfields of structured :86: information
+var mt940Subfield = regexp.MustCompile(`\?(\d{2})`)
+
+// mt940Field is a field of an MT940 statement, continuation lines included
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+func parseMT940(data string) ([]Incoming, error) {
+	var credits []Incoming
+	var account, currency string
+	var current *Incoming // The last credit, waiting for its :86: information
+
+	for _, field := range mt940Fields(data) {
+		switch field.tag {
+		case "25":
+			account = normalize(field.value)
+			if i := strings.LastIndex(account, "/"); i >= 0 {
+				account = account[i+1:]
+			}
+		case "60F", "60M":
+			if len(field.value) >= 10 {
+				currency = field.value[7:10]
+			}
+		case "61":
+			current = nil
+			m := mt940Line.FindStringSubmatch(field.value)
+			if m == nil {
+				return nil, fmt.Errorf("%w: invalid statement line %q", ErrInvalidStatement, field.value)
+			}
+			if m[3] != "C" {
+				continue
+			}
+			bookedAt, err := mt940Date(m[1], m[2])
+			if err != nil {
+				return nil, err
+			}
+			cents, err := parseAmount(m[5])
+			if err != nil {
+				return nil, err
+			}
+
+			reference := mt940Reference(m[7])
+			if reference == "" {
+				reference = mt940Reference(m[6])
+			}
+			credits = append(credits, Incoming{
+				BankReference: reference,
+				Amount:        cents,
+				Currency:      currency,
+				BookedAt:      bookedAt,
+				CreditedIBAN:  account,
+			})
+			current = &credits[len(credits)-1]
+		case "86":
+			if current != nil {
+				current.PayerName, current.PayerIBAN, current.RemittanceInfo = parseMT940Information(field.value)
+				current = nil
+			}
+		}
+	}
+	return credits, nil
+}
+
+// mt940Fields splits a statement into its fields, joining continuation lines without
+// separator as they are wrapped at a fixed width
+func mt940Fields(data string) []mt940Field {
+	var fields []mt940Field
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " ")
+		if strings.HasPrefix(line, ":") {
+			if end := strings.Index(line[1:], ":"); end > 0 {
+				fields = append(fields, mt940Field{tag: line[1 : end+1], value: line[end+2:]})
+				continue
+			}
+		}
+		if len(fields) == 0 || line == "-" || strings.HasPrefix(line, "-}") {
+			continue
+		}
+		last := &fields[len(fields)-1]
+		if last.tag == "61" {
+			// The supplementary details of a statement line start on a new line
+			last.value += "\n" + line
+		} else {
+			last.value += line
+		}
+	}
+	return fields
+}
+
+func mt940Reference(reference string) string {
+	reference = strings.TrimSpace(reference)
+	if strings.EqualFold(reference, "NONREF") {
+		return ""
+	}
+	return reference
+}
+
+// mt940Date reads the value date, and the entry date when given, of a statement line
+func mt940Date(valueDate, entryDate string) (time.Time, error) {
+	value, err := time.Parse("060102", valueDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid value date %q", ErrInvalidStatement, valueDate)
+	}
+	if entryDate == "" {
+		return value, nil
+	}
+	entry, err := time.Parse("0102", entryDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid entry date %q", ErrInvalidStatement, entryDate)
+	}
+
+	// The entry date has no year, it may be booked across the new year
+	year := value.Year()
+	switch {
+	case entry.Month() == time.December && value.Month() == time.January:
+		year--
+	case entry.Month() == time.January && value.Month() == time.December:
+		year++
+	}
+	return time.Date(year, entry.Month(), entry.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseMT940Information reads the payer and the remittance information of a :86:
+// field, either in ?NN subfields (German banks), in /TAG/ subfields (Dutch banks) or
+// in free text
+func parseMT940Information(info string) (payer, iban, remittance string) {
+	if indexes := mt940Subfield.FindAllStringSubmatchIndex(info, -1); len(indexes) > 0 {
+		var remittanceParts, payerParts []string
+		for n, index := range indexes {
+			end := len(info)
+			if n+1 < len(indexes) {
+				end = indexes[n+1][0]
+			}
+			value := info[index[1]:end]
+			code, _ := strconv.Atoi(info[index[2]:index[3]])
+			switch {
+			case code >= 20 && code <= 29, code >= 60 && code <= 63:
+				remittanceParts = append(remittanceParts, value)
+			case code == 31:
+				iban = normalize(value)
+			case code == 32 || code == 33:
+				payerParts = append(payerParts, value)
+			}
+		}
+		return strings.TrimSpace(strings.Join(payerParts, "")), iban, strings.TrimSpace(strings.Join(remittanceParts, ""))
+	}
+
+	if strings.HasPrefix(info, "/") {
+		tags := strings.Split(info, "/")
+		for i := 1; i+1 < len(tags); i++ {
+			switch tags[i] {
+			case "NAME":
+				payer = tags[i+1]
+			case "IBAN":
+				iban = normalize(tags[i+1])
+			case "CNTP":
+				// Counterparty: IBAN, BIC, name and city
+				iban = normalize(tags[i+1])
+				if i+3 < len(tags) {
+					payer = tags[i+3]
+				}
+			case "REMI":
+				remittance = strings.Join(tags[i+1:], " ")
+			}
+		}
+		if remittance != "" {
+			return strings.TrimSpace(payer), iban, strings.Join(strings.Fields(remittance), " ")
+		}
+	}
+	return "", "", strings.TrimSpace(info)
+}
+
+// parseAmount converts an amount with a decimal point or comma to cents
+func parseAmount(amount string) (int64, error) {
+	amount = strings.ReplaceAll(strings.TrimSpace(amount), ",", ".")
+	units, decimals, _ := strings.Cut(amount, ".")
+	if len(decimals) > 2 {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidStatement, amount)
+	}
+	decimals += strings.Repeat("0", 2-len(decimals))
+	cents, err := strconv.ParseInt(units+decimals, 10, 64)
+	if err != nil || cents < 0 {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidStatement, amount)
+	}
+	return cents, nil
+}
@@ -0,0 +1,160 @@
+package banktransfer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const camt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Acct><Id><IBAN>BE68539007547034</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">1250.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-07-01</Dt></BookgDt>
+        <AcctSvcrRef>2026070100001</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <RltdPties>
+            <Dbtr><Nm>ACME Corp</Nm></Dbtr>
+            <DbtrAcct><Id><IBAN>FR7630006000011234567890189</IBAN></Id></DbtrAcct>
+          </RltdPties>
+          <RmtInf><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">80.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-07-01</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">300.50</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-07-02</Dt></BookgDt>
+        <AcctSvcrRef>BATCH42</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">100.50</Amt>
+            <RmtInf><Ustrd>Wallets team A</Ustrd></RmtInf>
+          </TxDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">200.00</Amt>
+            <RltdPties><CdtrAcct><Id><IBAN>BE71 0961 2345 6769</IBAN></Id></CdtrAcct></RltdPties>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+        <BookgDt><Dt>2026-07-02</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+const mt940 = `{1:F01BANKBEBBAXXX0000000000}{2:O940}{4:
+:20:STATEMENT
+:25:BE68539007547034
+:28C:00042/001
+:60F:C260630EUR10000,00
+:61:2607010701C1250,00NTRFNONREF//B6G01XXX
+:86:/EREF/NOTPROVIDED/CNTP/FR7630006000011234567890189/BNPAFRPP/ACME C
+ORP/PARIS/REMI/USTD//RF18 5390 0754 7034/
+:61:2607020702D80,00NTRFNONREF
+:86:Card settlement
+:61:2607020702C50,00NTRFINV123
+:86:166?00GUTSCHRIFT?20SVWZ+Wallet top-up?21RF18 5390?220754 7034?31DE89370400440532013000?32MAX MUSTER
+MANN
+:61:2607020702C50,00NTRFNONREF
+:86:top-up
+:61:2607020702C50,00NTRFNONREF
+:86:top-up
+:62F:C260702EUR11320,00
+-}`
+
+func TestReference(t *testing.T) {
+	assert.True(t, ValidReference("RF18539007547034"))
+	assert.True(t, ValidReference("rf18 5390 0754 7034"))
+	assert.False(t, ValidReference("RF19539007547034"))
+	assert.False(t, ValidReference("BE18539007547034"))
+
+	for i := 0; i < 20; i++ {
+		reference, err := NewReference()
+		require.NoError(t, err)
+		assert.Len(t, reference, 16)
+		assert.True(t, ValidReference(reference), reference)
+	}
+
+	references := FindReferences("Payment rf18 5390 0754 7034 group booking")
+	assert.Contains(t, references, "RF18539007547034")
+	assert.Empty(t, FindReferences("Wallet top-up for the team"))
+}
+
+func TestParseStatement_CAMT053(t *testing.T) {
+	source, credits, err := ParseStatement([]byte(camt053))
+	require.NoError(t, err)
+	assert.Equal(t, SourceCAMT053, source)
+	require.Len(t, credits, 3, "debits and pending entries left out")
+
+	assert.Equal(t, Incoming{
+		BankReference:  "2026070100001",
+		Amount:         125000,
+		Currency:       "EUR",
+		BookedAt:       time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		PayerName:      "ACME Corp",
+		PayerIBAN:      "FR7630006000011234567890189",
+		CreditedIBAN:   "BE68539007547034",
+		RemittanceInfo: "RF18539007547034",
+	}, credits[0])
+
+	assert.Equal(t, "BATCH42/1", credits[1].BankReference)
+	assert.Equal(t, int64(10050), credits[1].Amount)
+	assert.Equal(t, "Wallets team A", credits[1].RemittanceInfo)
+	assert.Equal(t, "BATCH42/2", credits[2].BankReference)
+	assert.Equal(t, int64(20000), credits[2].Amount)
+	assert.Equal(t, "BE71096123456769", credits[2].CreditedIBAN, "virtual IBAN of the batch transfer")
+}
+
+func TestParseStatement_MT940(t *testing.T) {
+	source, credits, err := ParseStatement([]byte(mt940))
+	require.NoError(t, err)
+	assert.Equal(t, SourceMT940, source)
+	require.Len(t, credits, 4)
+
+	assert.Equal(t, "B6G01XXX", credits[0].BankReference)
+	assert.Equal(t, int64(125000), credits[0].Amount)
+	assert.Equal(t, "EUR", credits[0].Currency)
+	assert.Equal(t, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), credits[0].BookedAt)
+	assert.Equal(t, "ACME CORP", credits[0].PayerName)
+	assert.Equal(t, "FR7630006000011234567890189", credits[0].PayerIBAN)
+	assert.Contains(t, FindReferences(credits[0].RemittanceInfo), "RF18539007547034")
+
+	assert.Equal(t, "INV123", credits[1].BankReference)
+	assert.Equal(t, "MAX MUSTERMANN", credits[1].PayerName)
+	assert.Equal(t, "DE89370400440532013000", credits[1].PayerIBAN)
+	assert.Contains(t, FindReferences(credits[1].RemittanceInfo), "RF18539007547034")
+
+	// Identical entries without reference get distinct fingerprints
+	assert.Contains(t, credits[2].BankReference, "fp:")
+	assert.NotEqual(t, credits[2].BankReference, credits[3].BankReference)
+
+	_, again, err := ParseStatement([]byte(mt940))
+	require.NoError(t, err)
+	assert.Equal(t, credits[3].BankReference, again[3].BankReference, "fingerprints are stable across imports")
+}
+
+func TestParseStatement_Invalid(t *testing.T) {
+	_, _, err := ParseStatement([]byte("date,amount\n2026-07-01,12.50"))
+	assert.ErrorIs(t, err, ErrInvalidStatement)
+
+	_, _, err = ParseStatement([]byte("<Document><Other/></Document>"))
+	assert.ErrorIs(t, err, ErrInvalidStatement)
+}
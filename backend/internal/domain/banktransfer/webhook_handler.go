@@ -0,0 +1,58 @@
+package banktransfer
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookHandler receives the transfers credited to virtual IBANs from the provider
+type WebhookHandler struct {
+	service *Service
+	secret  string // Shared token expected from the provider
+}
+
+func NewWebhookHandler(service *Service, secret string) *WebhookHandler {
+	return &WebhookHandler{service: service, secret: secret}
+}
+
+// RegisterWebhookRoutes registers the provider webhook endpoint (no auth required)
+func (h *WebhookHandler) RegisterWebhookRoutes(r *gin.RouterGroup) {
+	r.POST("/provider", h.HandleProvider)
+}
+
+// HandleProvider records a transfer to a virtual IBAN and matches it to its order. A
+// notification sent again is acknowledged without recording the transfer twice.
+func (h *WebhookHandler) HandleProvider(c *gin.Context) {
+	if h.secret == "" {
+		response.ServiceUnavailable(c, "bank transfer webhook secret not configured")
+		return
+	}
+	token := c.GetHeader("X-Webhook-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) != 1 {
+		response.Unauthorized(c, "Invalid webhook token")
+		return
+	}
+
+	var notification ProviderNotification
+	if err := c.ShouldBindJSON(&notification); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	transfer, err := h.service.HandleNotification(c.Request.Context(), notification)
+	if err != nil {
+		if errors.Is(err, ErrOrderNotFound) {
+			log.Warn().Str("iban", notification.IBAN).Msg("Bank transfer to an unknown virtual IBAN")
+			response.NotFound(c, "No top-up order for this IBAN")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, gin.H{"id": transfer.ID, "status": transfer.Status})
+}
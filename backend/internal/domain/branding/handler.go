@@ -0,0 +1,244 @@
+package branding
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped branding management routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	branding := r.Group("/branding")
+	{
+		branding.GET("", h.Get)
+		branding.PATCH("", h.Update)
+		branding.PUT("/:asset", h.UploadAsset)
+		branding.DELETE("/:asset", h.RemoveAsset)
+	}
+}
+
+// RegisterPublicRoutes registers the unauthenticated config routes used by apps
+func (h *Handler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET("/festivals/:id/config", h.GetPublicConfig)
+	r.GET("/config", h.GetPublicConfigByDomain)
+}
+
+// Get returns the festival branding
+// @Summary Get festival branding
+// @Description Get the logo, icon, colors, email sender and custom domain of a festival
+// @Tags branding
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Branding} "Festival branding"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/branding [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	branding, err := h.service.Get(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, branding)
+}
+
+// Update updates the festival branding
+// @Summary Update festival branding
+// @Description Update colors, email sender name, reply-to address and custom domain; empty values reset a field to its default
+// @Tags branding
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body UpdateBrandingRequest true "Branding changes"
+// @Success 200 {object} response.Response{data=Branding} "Branding updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid value"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Custom domain already used"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/branding [patch]
+func (h *Handler) Update(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req UpdateBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	branding, err := h.service.Update(c.Request.Context(), festivalID, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, branding)
+}
+
+// UploadAsset uploads the festival logo or icon
+// @Summary Upload branding image
+// @Description Upload a logo (at least 128x32, resized to fit 1024x512) or a square icon (at least 192x192, resized to 512x512)
+// @Tags branding
+// @Accept multipart/form-data
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param asset path string true "Asset" Enums(logo, icon)
+// @Param file formData file true "JPEG, PNG, GIF or WebP image"
+// @Success 200 {object} response.Response{data=Branding} "Image stored"
+// @Failure 400 {object} response.ErrorResponse "Invalid image"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 503 {object} response.ErrorResponse "Uploads not configured"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/branding/{asset} [put]
+func (h *Handler) UploadAsset(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "MISSING_FILE", "No file provided", nil)
+		return
+	}
+
+	branding, err := h.service.UploadAsset(c.Request.Context(), festivalID, currentUser(c), Asset(c.Param("asset")), file)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, branding)
+}
+
+// RemoveAsset removes the festival logo or icon
+// @Summary Remove branding image
+// @Description Remove the logo or icon; apps fall back to the platform defaults
+// @Tags branding
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param asset path string true "Asset" Enums(logo, icon)
+// @Success 200 {object} response.Response{data=Branding} "Image removed"
+// @Failure 400 {object} response.ErrorResponse "Unknown asset"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/branding/{asset} [delete]
+func (h *Handler) RemoveAsset(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	branding, err := h.service.RemoveAsset(c.Request.Context(), festivalID, currentUser(c), Asset(c.Param("asset")))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, branding)
+}
+
+// GetPublicConfig returns the public config of a festival
+// @Summary Get festival app config
+// @Description Get the name, branding and link domain of a festival by ID or slug (no auth required)
+// @Tags branding
+// @Produce json
+// @Param id path string true "Festival ID or slug"
+// @Success 200 {object} response.Response{data=PublicConfig} "Festival config"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Router /festivals/{id}/config [get]
+func (h *Handler) GetPublicConfig(c *gin.Context) {
+	config, err := h.service.GetPublicConfig(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	response.OK(c, config)
+}
+
+// GetPublicConfigByDomain returns the public config of the festival using a custom domain
+// @Summary Get festival app config by domain
+// @Description Resolve a white-label custom domain to its festival config (no auth required)
+// @Tags branding
+// @Produce json
+// @Param domain query string true "Custom domain, e.g. app.myfestival.com"
+// @Success 200 {object} response.Response{data=PublicConfig} "Festival config"
+// @Failure 400 {object} response.ErrorResponse "Missing domain"
+// @Failure 404 {object} response.ErrorResponse "No festival uses the domain"
+// @Router /config [get]
+func (h *Handler) GetPublicConfigByDomain(c *gin.Context) {
+	domain := c.Query("domain")
+	if domain == "" {
+		response.BadRequest(c, "MISSING_DOMAIN", "The domain query parameter is required", nil)
+		return
+	}
+
+	config, err := h.service.GetPublicConfigByDomain(c.Request.Context(), domain)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	response.OK(c, config)
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	var appErr *errors.AppError
+
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrInvalidColor):
+		response.BadRequest(c, "INVALID_COLOR", err.Error(), nil)
+	case errors.Is(err, ErrInvalidSenderName):
+		response.BadRequest(c, "INVALID_SENDER_NAME", err.Error(), nil)
+	case errors.Is(err, ErrInvalidReplyTo):
+		response.BadRequest(c, "INVALID_REPLY_TO", err.Error(), nil)
+	case errors.Is(err, ErrInvalidDomain):
+		response.BadRequest(c, "INVALID_DOMAIN", err.Error(), nil)
+	case errors.Is(err, ErrInvalidAsset):
+		response.BadRequest(c, "INVALID_ASSET", "Asset must be logo or icon", nil)
+	case errors.Is(err, ErrDomainTaken):
+		response.Conflict(c, "DOMAIN_TAKEN", err.Error())
+	case errors.Is(err, ErrUploadsUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	case errors.As(err, &appErr):
+		// Image validation errors from the media pipeline
+		response.BadRequest(c, appErr.Code, appErr.Message, nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,253 @@
+package branding
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/media"
+)
+
+// Default colors used when a festival has not set its own
+const (
+	DefaultPrimaryColor   = "#6366f1"
+	DefaultSecondaryColor = "#1f2937"
+	DefaultAccentColor    = "#f59e0b"
+)
+
+// MaxSenderNameLength is the longest email sender display name accepted
+const MaxSenderNameLength = 64
+
+// Branding errors
+var (
+	ErrFestivalNotFound   = errors.New("festival not found")
+	ErrInvalidColor       = errors.New("color must be a hex value like #1a2b3c")
+	ErrInvalidSenderName  = errors.New("invalid sender name")
+	ErrInvalidReplyTo     = errors.New("invalid reply-to address")
+	ErrInvalidDomain      = errors.New("invalid custom domain")
+	ErrDomainTaken        = errors.New("custom domain is already used by another festival")
+	ErrInvalidAsset       = errors.New("unknown branding asset")
+	ErrUploadsUnavailable = errors.New("asset uploads are not configured")
+)
+
+// Branding holds the white-label configuration of a festival
+type Branding struct {
+	FestivalID      uuid.UUID  `json:"festivalId" gorm:"type:uuid;primary_key"`
+	LogoURL         string     `json:"logoUrl,omitempty"`
+	LogoMediaID     *uuid.UUID `json:"logoMediaId,omitempty" gorm:"type:uuid"`
+	IconURL         string     `json:"iconUrl,omitempty"`
+	IconMediaID     *uuid.UUID `json:"iconMediaId,omitempty" gorm:"type:uuid"`
+	PrimaryColor    string     `json:"primaryColor,omitempty"`
+	SecondaryColor  string     `json:"secondaryColor,omitempty"`
+	AccentColor     string     `json:"accentColor,omitempty"`
+	EmailSenderName string     `json:"emailSenderName,omitempty"`
+	EmailReplyTo    string     `json:"emailReplyTo,omitempty"`
+	CustomDomain    *string    `json:"customDomain,omitempty" gorm:"uniqueIndex"` // Host used in links sent to attendees
+	UpdatedBy       *uuid.UUID `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+func (Branding) TableName() string {
+	return "festival_branding"
+}
+
+// Asset is an image slot of the branding
+type Asset string
+
+const (
+	AssetLogo Asset = "logo" // Wide logo shown in app headers and emails
+	AssetIcon Asset = "icon" // Square app icon and favicon
+)
+
+// IsValid checks if the asset is valid
+func (a Asset) IsValid() bool {
+	switch a {
+	case AssetLogo, AssetIcon:
+		return true
+	default:
+		return false
+	}
+}
+
+// ImageVariant returns the size constraints uploads for the asset are resized to
+func (a Asset) ImageVariant() media.ImageVariantConfig {
+	switch a {
+	case AssetIcon:
+		return media.ImageVariantConfig{
+			MaxWidth:    512,
+			MaxHeight:   512,
+			MinWidth:    192,
+			MinHeight:   192,
+			Square:      true,
+			MaxFileSize: 2 * 1024 * 1024, // 2MB
+			Public:      true,
+		}
+	default:
+		return media.ImageVariantConfig{
+			MaxWidth:    1024,
+			MaxHeight:   512,
+			MinWidth:    128,
+			MinHeight:   32,
+			MaxFileSize: 5 * 1024 * 1024, // 5MB
+			Public:      true,
+		}
+	}
+}
+
+// FestivalInfo is the part of a festival exposed in its public config
+type FestivalInfo struct {
+	ID           uuid.UUID `gorm:"column:id"`
+	Name         string    `gorm:"column:name"`
+	Slug         string    `gorm:"column:slug"`
+	Timezone     string    `gorm:"column:timezone"`
+	CurrencyName string    `gorm:"column:currency_name"`
+}
+
+// UpdateBrandingRequest represents a branding update; omitted fields are left
+// unchanged and empty strings reset a field to its default
+type UpdateBrandingRequest struct {
+	PrimaryColor    *string `json:"primaryColor,omitempty"`
+	SecondaryColor  *string `json:"secondaryColor,omitempty"`
+	AccentColor     *string `json:"accentColor,omitempty"`
+	EmailSenderName *string `json:"emailSenderName,omitempty"`
+	EmailReplyTo    *string `json:"emailReplyTo,omitempty"`
+	CustomDomain    *string `json:"customDomain,omitempty"`
+}
+
+// Colors is the color palette of a festival
+type Colors struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+	Accent    string `json:"accent"`
+}
+
+// PublicConfig is the unauthenticated configuration consumed by the apps
+type PublicConfig struct {
+	FestivalID      uuid.UUID `json:"festivalId"`
+	Name            string    `json:"name"`
+	Slug            string    `json:"slug"`
+	Timezone        string    `json:"timezone"`
+	CurrencyName    string    `json:"currencyName"`
+	LogoURL         string    `json:"logoUrl,omitempty"`
+	IconURL         string    `json:"iconUrl,omitempty"`
+	Colors          Colors    `json:"colors"`
+	EmailSenderName string    `json:"emailSenderName"`
+	LinkBaseURL     string    `json:"linkBaseUrl,omitempty"` // Set when the festival uses a custom domain
+}
+
+// EmailBranding is what email templates need to render a festival's branding
+type EmailBranding struct {
+	SenderName   string
+	ReplyTo      string
+	LogoURL      string
+	PrimaryColor string
+	LinkBaseURL  string
+}
+
+// Palette returns the festival colors with defaults applied
+func (b *Branding) Palette() Colors {
+	return Colors{
+		Primary:   withDefault(b.PrimaryColor, DefaultPrimaryColor),
+		Secondary: withDefault(b.SecondaryColor, DefaultSecondaryColor),
+		Accent:    withDefault(b.AccentColor, DefaultAccentColor),
+	}
+}
+
+// LinkBaseURL returns the base URL of links on the custom domain, or "" without one
+func (b *Branding) LinkBaseURL() string {
+	if b.CustomDomain == nil || *b.CustomDomain == "" {
+		return ""
+	}
+	return "https://" + *b.CustomDomain
+}
+
+// ToPublicConfig builds the public config of a festival
+func (b *Branding) ToPublicConfig(festival *FestivalInfo) PublicConfig {
+	return PublicConfig{
+		FestivalID:      festival.ID,
+		Name:            festival.Name,
+		Slug:            festival.Slug,
+		Timezone:        festival.Timezone,
+		CurrencyName:    festival.CurrencyName,
+		LogoURL:         b.LogoURL,
+		IconURL:         b.IconURL,
+		Colors:          b.Palette(),
+		EmailSenderName: withDefault(b.EmailSenderName, festival.Name),
+		LinkBaseURL:     b.LinkBaseURL(),
+	}
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// NormalizeColor validates a hex color and returns it lowercased in #rrggbb form
+func NormalizeColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if !hexColorPattern.MatchString(color) {
+		return "", ErrInvalidColor
+	}
+	if len(color) == 4 {
+		color = fmt.Sprintf("#%c%c%c%c%c%c", color[1], color[1], color[2], color[2], color[3], color[3])
+	}
+	return color, nil
+}
+
+// NormalizeSenderName validates an email sender display name
+func NormalizeSenderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len([]rune(name)) > MaxSenderNameLength {
+		return "", fmt.Errorf("%w: at most %d characters", ErrInvalidSenderName, MaxSenderNameLength)
+	}
+	// Characters that would break or inject into the From header
+	if strings.ContainsAny(name, "\r\n<>\"@") {
+		return "", fmt.Errorf("%w: must not contain line breaks, quotes, < > or @", ErrInvalidSenderName)
+	}
+	return name, nil
+}
+
+// NormalizeReplyTo validates a bare reply-to email address
+func NormalizeReplyTo(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || parsed.Address != address {
+		return "", ErrInvalidReplyTo
+	}
+	return strings.ToLower(address), nil
+}
+
+var domainLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// NormalizeDomain validates a custom domain host name (no scheme, port or path)
+func NormalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" || len(domain) > 253 || net.ParseIP(domain) != nil {
+		return "", ErrInvalidDomain
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+	for _, label := range labels {
+		if !domainLabelPattern.MatchString(label) {
+			return "", ErrInvalidDomain
+		}
+	}
+	// The top-level domain is never all digits
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", ErrInvalidDomain
+	}
+	return domain, nil
+}
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,149 @@
+package branding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeColor tests hex color validation
+func TestNormalizeColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"#1A2B3C", "#1a2b3c", false},
+		{" #abc ", "#aabbcc", false},
+		{"1a2b3c", "", true},
+		{"#1a2b3", "", true},
+		{"#ggg", "", true},
+		{"red", "", true},
+		{"#1a2b3c;background:url(x)", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeColor(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidColor)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// TestNormalizeSenderName tests email sender name validation
+func TestNormalizeSenderName(t *testing.T) {
+	name, err := NormalizeSenderName("  Rock Werchter Tickets ")
+	require.NoError(t, err)
+	assert.Equal(t, "Rock Werchter Tickets", name)
+
+	name, err = NormalizeSenderName("Dour Festival – Billetterie")
+	require.NoError(t, err)
+	assert.Equal(t, "Dour Festival – Billetterie", name)
+
+	for _, invalid := range []string{
+		"Festival\r\nBcc: victim@example.com",
+		`Festival "Official"`,
+		"Festival <noreply@example.com>",
+		strings.Repeat("a", MaxSenderNameLength+1),
+	} {
+		_, err := NormalizeSenderName(invalid)
+		assert.ErrorIs(t, err, ErrInvalidSenderName, invalid)
+	}
+}
+
+// TestNormalizeReplyTo tests reply-to address validation
+func TestNormalizeReplyTo(t *testing.T) {
+	address, err := NormalizeReplyTo(" Info@MyFestival.be ")
+	require.NoError(t, err)
+	assert.Equal(t, "info@myfestival.be", address)
+
+	for _, invalid := range []string{"not-an-email", "Info <info@myfestival.be>", "info@myfestival.be, other@example.com"} {
+		_, err := NormalizeReplyTo(invalid)
+		assert.ErrorIs(t, err, ErrInvalidReplyTo, invalid)
+	}
+}
+
+// TestNormalizeDomain tests custom domain validation
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"App.MyFestival.com", "app.myfestival.com", false},
+		{"tickets.my-festival.be.", "tickets.my-festival.be", false},
+		{"localhost", "", true},
+		{"https://app.myfestival.com", "", true},
+		{"app.myfestival.com/path", "", true},
+		{"app.myfestival.com:8080", "", true},
+		{"-bad.myfestival.com", "", true},
+		{"192.168.1.10", "", true},
+		{"app..myfestival.com", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeDomain(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidDomain)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// TestToPublicConfig tests defaults applied to the public config
+func TestToPublicConfig(t *testing.T) {
+	festival := &FestivalInfo{ID: uuid.New(), Name: "Summer Fest", Slug: "summer-fest", Timezone: "Europe/Brussels", CurrencyName: "Jetons"}
+
+	t.Run("defaults", func(t *testing.T) {
+		config := (&Branding{FestivalID: festival.ID}).ToPublicConfig(festival)
+		assert.Equal(t, festival.ID, config.FestivalID)
+		assert.Equal(t, Colors{Primary: DefaultPrimaryColor, Secondary: DefaultSecondaryColor, Accent: DefaultAccentColor}, config.Colors)
+		assert.Equal(t, "Summer Fest", config.EmailSenderName)
+		assert.Empty(t, config.LinkBaseURL)
+	})
+
+	t.Run("custom branding", func(t *testing.T) {
+		domain := "app.summerfest.be"
+		config := (&Branding{
+			FestivalID:      festival.ID,
+			LogoURL:         "https://cdn.example.com/logo.png",
+			PrimaryColor:    "#ff0000",
+			EmailSenderName: "Summer Fest Tickets",
+			CustomDomain:    &domain,
+		}).ToPublicConfig(festival)
+		assert.Equal(t, "#ff0000", config.Colors.Primary)
+		assert.Equal(t, DefaultSecondaryColor, config.Colors.Secondary)
+		assert.Equal(t, "Summer Fest Tickets", config.EmailSenderName)
+		assert.Equal(t, "https://app.summerfest.be", config.LinkBaseURL)
+		assert.Equal(t, "https://cdn.example.com/logo.png", config.LogoURL)
+	})
+}
+
+// TestAssetImageVariant tests the size constraints of branding images
+func TestAssetImageVariant(t *testing.T) {
+	assert.True(t, AssetLogo.IsValid())
+	assert.True(t, AssetIcon.IsValid())
+	assert.False(t, Asset("banner").IsValid())
+
+	icon := AssetIcon.ImageVariant()
+	assert.True(t, icon.Square)
+	assert.Equal(t, 512, icon.MaxWidth)
+	assert.True(t, icon.Public)
+
+	logo := AssetLogo.ImageVariant()
+	assert.False(t, logo.Square)
+	assert.Greater(t, logo.MaxWidth, logo.MaxHeight)
+}
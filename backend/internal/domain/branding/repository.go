@@ -0,0 +1,98 @@
+package branding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Get(ctx context.Context, festivalID uuid.UUID) (*Branding, error)
+	GetByCustomDomain(ctx context.Context, domain string) (*Branding, error)
+	Save(ctx context.Context, branding *Branding) error
+	GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error)
+	GetFestivalBySlug(ctx context.Context, slug string) (*FestivalInfo, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Get(ctx context.Context, festivalID uuid.UUID) (*Branding, error) {
+	var branding Branding
+	err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).First(&branding).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get branding: %w", err)
+	}
+	return &branding, nil
+}
+
+func (r *repository) GetByCustomDomain(ctx context.Context, domain string) (*Branding, error) {
+	var branding Branding
+	err := r.db.WithContext(ctx).Where("custom_domain = ?", domain).First(&branding).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get branding by domain: %w", err)
+	}
+	return &branding, nil
+}
+
+// Save inserts or replaces the branding of a festival
+func (r *repository) Save(ctx context.Context, branding *Branding) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "festival_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"logo_url", "logo_media_id", "icon_url", "icon_media_id",
+			"primary_color", "secondary_color", "accent_color",
+			"email_sender_name", "email_reply_to", "custom_domain",
+			"updated_by", "updated_at",
+		}),
+	}).Create(branding).Error
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDomainTaken
+		}
+		return fmt.Errorf("failed to save branding: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	return r.getFestival(ctx, "id = ?", festivalID)
+}
+
+func (r *repository) GetFestivalBySlug(ctx context.Context, slug string) (*FestivalInfo, error) {
+	return r.getFestival(ctx, "slug = ?", slug)
+}
+
+func (r *repository) getFestival(ctx context.Context, query string, arg interface{}) (*FestivalInfo, error) {
+	var festivals []FestivalInfo
+	err := r.db.WithContext(ctx).
+		Table("public.festivals").
+		Select("id, name, slug, timezone, currency_name").
+		Where(query, arg).
+		Limit(1).
+		Scan(&festivals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(festivals) == 0 {
+		return nil, nil
+	}
+	return &festivals[0], nil
+}
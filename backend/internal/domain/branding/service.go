@@ -0,0 +1,385 @@
+package branding
+
+import (
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/media"
+	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// publicConfigTTL bounds how long apps may see a stale config after an update
+// that could not invalidate every cached lookup
+const publicConfigTTL = 5 * time.Minute
+
+// AssetUploader stores branding images through the media pipeline; satisfied by media.Service
+type AssetUploader interface {
+	UploadImageVariant(ctx context.Context, file *multipart.FileHeader, festivalID *uuid.UUID, uploadedBy *uuid.UUID, variant media.ImageVariantConfig) (*media.Media, error)
+	DeleteMedia(ctx context.Context, id uuid.UUID) error
+}
+
+type Service struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	uploader    AssetUploader
+}
+
+// NewService creates a new branding service; redisClient may be nil to disable caching
+func NewService(repo Repository, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+	}
+}
+
+// SetAssetUploader sets the media pipeline used for logo and icon uploads
+func (s *Service) SetAssetUploader(uploader AssetUploader) {
+	s.uploader = uploader
+}
+
+// Get returns the branding of a festival, empty when it was never configured
+func (s *Service) Get(ctx context.Context, festivalID uuid.UUID) (*Branding, error) {
+	branding, err := s.repo.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if branding == nil {
+		return &Branding{FestivalID: festivalID}, nil
+	}
+	return branding, nil
+}
+
+// Update validates and applies a branding update
+func (s *Service) Update(ctx context.Context, festivalID uuid.UUID, updatedBy *uuid.UUID, req UpdateBrandingRequest) (*Branding, error) {
+	branding, err := s.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	previousDomain := branding.CustomDomain
+
+	colors := []struct {
+		value  *string
+		target *string
+	}{
+		{req.PrimaryColor, &branding.PrimaryColor},
+		{req.SecondaryColor, &branding.SecondaryColor},
+		{req.AccentColor, &branding.AccentColor},
+	}
+	for _, color := range colors {
+		if color.value == nil {
+			continue
+		}
+		if *color.value == "" {
+			*color.target = ""
+			continue
+		}
+		normalized, err := NormalizeColor(*color.value)
+		if err != nil {
+			return nil, err
+		}
+		*color.target = normalized
+	}
+
+	if req.EmailSenderName != nil {
+		name, err := NormalizeSenderName(*req.EmailSenderName)
+		if err != nil {
+			return nil, err
+		}
+		branding.EmailSenderName = name
+	}
+
+	if req.EmailReplyTo != nil {
+		branding.EmailReplyTo = ""
+		if *req.EmailReplyTo != "" {
+			replyTo, err := NormalizeReplyTo(*req.EmailReplyTo)
+			if err != nil {
+				return nil, err
+			}
+			branding.EmailReplyTo = replyTo
+		}
+	}
+
+	if req.CustomDomain != nil {
+		branding.CustomDomain = nil
+		if *req.CustomDomain != "" {
+			domain, err := NormalizeDomain(*req.CustomDomain)
+			if err != nil {
+				return nil, err
+			}
+			branding.CustomDomain = &domain
+		}
+	}
+
+	if err := s.save(ctx, branding, updatedBy, previousDomain); err != nil {
+		return nil, err
+	}
+	return branding, nil
+}
+
+// UploadAsset resizes an uploaded image, stores it and sets it as the festival logo or icon
+func (s *Service) UploadAsset(ctx context.Context, festivalID uuid.UUID, uploadedBy *uuid.UUID, asset Asset, file *multipart.FileHeader) (*Branding, error) {
+	if !asset.IsValid() {
+		return nil, ErrInvalidAsset
+	}
+	if s.uploader == nil {
+		return nil, ErrUploadsUnavailable
+	}
+
+	branding, err := s.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded, err := s.uploader.UploadImageVariant(ctx, file, &festivalID, uploadedBy, asset.ImageVariant())
+	if err != nil {
+		return nil, err
+	}
+
+	previousMediaID := setAsset(branding, asset, uploaded.URL, &uploaded.ID)
+
+	if err := s.save(ctx, branding, uploadedBy, branding.CustomDomain); err != nil {
+		s.deleteMedia(ctx, &uploaded.ID)
+		return nil, err
+	}
+	s.deleteMedia(ctx, previousMediaID)
+
+	return branding, nil
+}
+
+// RemoveAsset clears the festival logo or icon
+func (s *Service) RemoveAsset(ctx context.Context, festivalID uuid.UUID, updatedBy *uuid.UUID, asset Asset) (*Branding, error) {
+	if !asset.IsValid() {
+		return nil, ErrInvalidAsset
+	}
+
+	branding, err := s.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if (asset == AssetLogo && branding.LogoURL == "") || (asset == AssetIcon && branding.IconURL == "") {
+		return branding, nil
+	}
+
+	previousMediaID := setAsset(branding, asset, "", nil)
+
+	if err := s.save(ctx, branding, updatedBy, branding.CustomDomain); err != nil {
+		return nil, err
+	}
+	s.deleteMedia(ctx, previousMediaID)
+
+	return branding, nil
+}
+
+// GetPublicConfig returns the public config of a festival looked up by ID or slug
+func (s *Service) GetPublicConfig(ctx context.Context, idOrSlug string) (*PublicConfig, error) {
+	key := s.keyBuilder.FestivalConfigKey(idOrSlug)
+	if config := s.cached(ctx, key); config != nil {
+		return config, nil
+	}
+
+	var festival *FestivalInfo
+	var err error
+	if id, parseErr := uuid.Parse(idOrSlug); parseErr == nil {
+		festival, err = s.repo.GetFestival(ctx, id)
+	} else {
+		festival, err = s.repo.GetFestivalBySlug(ctx, idOrSlug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	branding, err := s.Get(ctx, festival.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := branding.ToPublicConfig(festival)
+	s.cache(ctx, key, &config)
+	return &config, nil
+}
+
+// GetPublicConfigByDomain returns the public config of the festival using a custom domain
+func (s *Service) GetPublicConfigByDomain(ctx context.Context, domain string) (*PublicConfig, error) {
+	domain, err := NormalizeDomain(domain)
+	if err != nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	key := s.keyBuilder.FestivalConfigKey("domain:" + domain)
+	if config := s.cached(ctx, key); config != nil {
+		return config, nil
+	}
+
+	branding, err := s.repo.GetByCustomDomain(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if branding == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	festival, err := s.repo.GetFestival(ctx, branding.FestivalID)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	config := branding.ToPublicConfig(festival)
+	s.cache(ctx, key, &config)
+	return &config, nil
+}
+
+// GetEmailBranding returns the sender and template branding of a festival's emails
+func (s *Service) GetEmailBranding(ctx context.Context, festivalID uuid.UUID) (*EmailBranding, error) {
+	festival, err := s.repo.GetFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	branding, err := s.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := branding.ToPublicConfig(festival)
+	return &EmailBranding{
+		SenderName:   config.EmailSenderName,
+		ReplyTo:      branding.EmailReplyTo,
+		LogoURL:      config.LogoURL,
+		PrimaryColor: config.Colors.Primary,
+		LinkBaseURL:  config.LinkBaseURL,
+	}, nil
+}
+
+// GetReportBranding returns the festival identity printed on documents rendered for attendees
+func (s *Service) GetReportBranding(ctx context.Context, festivalID uuid.UUID) (*reports.Branding, error) {
+	festival, err := s.repo.GetFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	branding, err := s.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reports.Branding{
+		FestivalName: festival.Name,
+		CurrencyName: festival.CurrencyName,
+		Timezone:     festival.Timezone,
+		PrimaryColor: branding.Palette().Primary,
+	}, nil
+}
+
+func (s *Service) save(ctx context.Context, branding *Branding, updatedBy *uuid.UUID, previousDomain *string) error {
+	now := time.Now()
+	if branding.CreatedAt.IsZero() {
+		branding.CreatedAt = now
+	}
+	branding.UpdatedAt = now
+	branding.UpdatedBy = updatedBy
+
+	if err := s.repo.Save(ctx, branding); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, branding, previousDomain)
+	return nil
+}
+
+// invalidate drops every cached lookup of the festival config
+func (s *Service) invalidate(ctx context.Context, branding *Branding, previousDomain *string) {
+	if s.redisClient == nil {
+		return
+	}
+
+	keys := []string{s.keyBuilder.FestivalConfigKey(branding.FestivalID.String())}
+	if festival, err := s.repo.GetFestival(ctx, branding.FestivalID); err == nil && festival != nil {
+		keys = append(keys, s.keyBuilder.FestivalConfigKey(festival.Slug))
+	}
+	for _, domain := range []*string{previousDomain, branding.CustomDomain} {
+		if domain != nil {
+			keys = append(keys, s.keyBuilder.FestivalConfigKey("domain:"+*domain))
+		}
+	}
+
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Warn().Err(err).Str("festival_id", branding.FestivalID.String()).Msg("Failed to invalidate festival config cache")
+	}
+}
+
+func (s *Service) cached(ctx context.Context, key string) *PublicConfig {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	data, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil
+	}
+
+	var config PublicConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil
+	}
+	return &config
+}
+
+func (s *Service) cache(ctx context.Context, key string, config *PublicConfig) {
+	if s.redisClient == nil {
+		return
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return
+	}
+	if err := s.redisClient.Set(ctx, key, data, publicConfigTTL).Err(); err != nil {
+		log.Warn().Err(err).Msg("Failed to cache festival config")
+	}
+}
+
+func (s *Service) deleteMedia(ctx context.Context, mediaID *uuid.UUID) {
+	if mediaID == nil || s.uploader == nil {
+		return
+	}
+	if err := s.uploader.DeleteMedia(ctx, *mediaID); err != nil {
+		log.Warn().Err(err).Str("media_id", mediaID.String()).Msg("Failed to delete replaced branding asset")
+	}
+}
+
+// setAsset points an asset slot at a new image and returns the media it replaced
+func setAsset(branding *Branding, asset Asset, url string, mediaID *uuid.UUID) *uuid.UUID {
+	var previous *uuid.UUID
+	switch asset {
+	case AssetLogo:
+		previous = branding.LogoMediaID
+		branding.LogoURL = url
+		branding.LogoMediaID = mediaID
+	case AssetIcon:
+		previous = branding.IconMediaID
+		branding.IconURL = url
+		branding.IconMediaID = mediaID
+	}
+	return previous
+}
@@ -0,0 +1,321 @@
+package budget
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped budget routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	budget := r.Group("/budget")
+	{
+		budget.GET("", h.GetReport)
+		budget.GET("/lines", h.ListLines)
+		budget.POST("/lines", h.CreateLine)
+		budget.PATCH("/lines/:lineId", h.UpdateLine)
+		budget.DELETE("/lines/:lineId", h.DeleteLine)
+		budget.GET("/lines/:lineId/entries", h.ListEntries)
+		budget.POST("/lines/:lineId/entries", h.CreateEntry)
+		budget.DELETE("/lines/:lineId/entries/:entryId", h.DeleteEntry)
+	}
+}
+
+// GetReport returns the budget tracked against actuals
+// @Summary Get budget report
+// @Description Track every budget line against its actuals, with the forecast at the current run-rate and whether the line is trending to miss
+// @Tags budget
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Report} "Budget report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget [get]
+func (h *Handler) GetReport(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	report, err := h.service.GetReport(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// ListLines lists the budget lines
+// @Summary List budget lines
+// @Description List the revenue and cost budget lines of the festival
+// @Tags budget
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Line} "Budget lines"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines [get]
+func (h *Handler) ListLines(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	lines, err := h.service.ListLines(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, lines)
+}
+
+// CreateLine adds a budget line
+// @Summary Create budget line
+// @Description Budget the revenue or cost of a category, with the source its actuals are tracked from
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateLineRequest true "Budget line"
+// @Success 201 {object} response.Response{data=Line} "Budget line created"
+// @Failure 400 {object} response.ErrorResponse "Invalid budget line"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Product category not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines [post]
+func (h *Handler) CreateLine(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	line, err := h.service.CreateLine(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, line)
+}
+
+// UpdateLine updates a budget line
+// @Summary Update budget line
+// @Description Update the amount, source, tolerance or period of a budget line
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lineId path string true "Budget line ID" format(uuid)
+// @Param request body UpdateLineRequest true "Budget line changes"
+// @Success 200 {object} response.Response{data=Line} "Budget line updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid budget line"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Budget line not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines/{lineId} [patch]
+func (h *Handler) UpdateLine(c *gin.Context) {
+	festivalID, lineID, ok := lineParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	line, err := h.service.UpdateLine(c.Request.Context(), festivalID, lineID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, line)
+}
+
+// DeleteLine deletes a budget line
+// @Summary Delete budget line
+// @Description Delete a budget line with its entries
+// @Tags budget
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lineId path string true "Budget line ID" format(uuid)
+// @Success 204 "Budget line deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Budget line not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines/{lineId} [delete]
+func (h *Handler) DeleteLine(c *gin.Context) {
+	festivalID, lineID, ok := lineParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteLine(c.Request.Context(), festivalID, lineID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListEntries lists the entries of a budget line
+// @Summary List budget entries
+// @Description List the actuals recorded on a MANUAL budget line
+// @Tags budget
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lineId path string true "Budget line ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Entry} "Budget entries"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Budget line not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines/{lineId}/entries [get]
+func (h *Handler) ListEntries(c *gin.Context) {
+	festivalID, lineID, ok := lineParams(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.ListEntries(c.Request.Context(), festivalID, lineID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, entries)
+}
+
+// CreateEntry records an actual on a budget line
+// @Summary Create budget entry
+// @Description Record an actual, e.g. an invoice, on a MANUAL budget line. Negative amounts correct earlier entries.
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lineId path string true "Budget line ID" format(uuid)
+// @Param request body CreateEntryRequest true "Budget entry"
+// @Success 201 {object} response.Response{data=Entry} "Budget entry recorded"
+// @Failure 400 {object} response.ErrorResponse "Invalid budget entry"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Budget line not found"
+// @Failure 409 {object} response.ErrorResponse "Line actuals are tracked automatically"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines/{lineId}/entries [post]
+func (h *Handler) CreateEntry(c *gin.Context) {
+	festivalID, lineID, ok := lineParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	entry, err := h.service.CreateEntry(c.Request.Context(), festivalID, lineID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, entry)
+}
+
+// DeleteEntry deletes an entry of a budget line
+// @Summary Delete budget entry
+// @Description Delete an actual recorded on a MANUAL budget line
+// @Tags budget
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lineId path string true "Budget line ID" format(uuid)
+// @Param entryId path string true "Budget entry ID" format(uuid)
+// @Success 204 "Budget entry deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Budget entry not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/budget/lines/{lineId}/entries/{entryId} [delete]
+func (h *Handler) DeleteEntry(c *gin.Context) {
+	festivalID, lineID, ok := lineParams(c)
+	if !ok {
+		return
+	}
+	entryID, err := uuid.Parse(c.Param("entryId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid budget entry ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteEntry(c.Request.Context(), festivalID, lineID, entryID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func lineParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	lineID, err := uuid.Parse(c.Param("lineId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid budget line ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, lineID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrLineNotFound):
+		response.NotFound(c, "Budget line not found")
+	case errors.Is(err, ErrEntryNotFound):
+		response.NotFound(c, "Budget entry not found")
+	case errors.Is(err, ErrCategoryNotFound):
+		response.NotFound(c, "Product category not found")
+	case errors.Is(err, ErrInvalidType), errors.Is(err, ErrInvalidSource), errors.Is(err, ErrCategoryRequired), errors.Is(err, ErrInvalidPeriod):
+		response.BadRequest(c, "INVALID_BUDGET_LINE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidAmount):
+		response.BadRequest(c, "INVALID_AMOUNT", err.Error(), nil)
+	case errors.Is(err, ErrManualEntriesOnly):
+		response.Conflict(c, "LINE_NOT_MANUAL", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
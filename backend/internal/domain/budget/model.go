@@ -0,0 +1,195 @@
+package budget
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget errors
+var (
+	ErrFestivalNotFound  = errors.New("festival not found")
+	ErrLineNotFound      = errors.New("budget line not found")
+	ErrEntryNotFound     = errors.New("budget entry not found")
+	ErrCategoryNotFound  = errors.New("product category not found")
+	ErrInvalidType       = errors.New("budget type must be REVENUE or COST")
+	ErrInvalidSource     = errors.New("actual source is not available for this budget type")
+	ErrCategoryRequired  = errors.New("product category lines need a categoryId")
+	ErrInvalidPeriod     = errors.New("period must end after it starts")
+	ErrInvalidAmount     = errors.New("entry amount must not be zero")
+	ErrManualEntriesOnly = errors.New("actuals can only be entered on MANUAL lines")
+)
+
+// Forecasting and alerts
+const (
+	DefaultTolerancePercent = 10
+	// MinAlertProgress is the share of the period that must have elapsed before a line
+	// alerts, so the first sales of the day do not project a miss
+	MinAlertProgress = 0.1
+)
+
+// LineType is whether a budget line plans income or spending
+type LineType string
+
+const (
+	LineTypeRevenue LineType = "REVENUE"
+	LineTypeCost    LineType = "COST"
+)
+
+// IsValid checks if the line type is valid
+func (t LineType) IsValid() bool {
+	return t == LineTypeRevenue || t == LineTypeCost
+}
+
+// Source is where the actuals of a budget line come from
+type Source string
+
+const (
+	SourceManual          Source = "MANUAL"           // Entries recorded by the organizer
+	SourceOrders          Source = "ORDERS"           // Paid orders of every stand
+	SourceProductCategory Source = "PRODUCT_CATEGORY" // Paid order items of a product category and its subcategories
+	SourceTickets         Source = "TICKETS"          // Tickets sold, at the price of their ticket type
+	SourceSettlements     Source = "SETTLEMENTS"      // Paid transfers to the festival Stripe account
+	SourcePaymentFees     Source = "PAYMENT_FEES"     // Platform fees of succeeded top-ups
+	SourceRefunds         Source = "REFUNDS"          // Succeeded top-up refunds
+)
+
+// ValidFor checks if the source can track lines of the given type
+func (s Source) ValidFor(t LineType) bool {
+	switch s {
+	case SourceManual:
+		return true
+	case SourceOrders, SourceProductCategory, SourceTickets, SourceSettlements:
+		return t == LineTypeRevenue
+	case SourcePaymentFees, SourceRefunds:
+		return t == LineTypeCost
+	default:
+		return false
+	}
+}
+
+// Status is how the forecast of a line compares with its budget
+type Status string
+
+const (
+	StatusNotStarted Status = "NOT_STARTED" // Period not started yet
+	StatusOnTrack    Status = "ON_TRACK"
+	StatusAtRisk     Status = "AT_RISK"   // Forecast misses the budget within the tolerance
+	StatusOffTrack   Status = "OFF_TRACK" // Forecast misses the budget beyond the tolerance
+)
+
+// Line is the budgeted revenue or cost of one category of a festival
+type Line struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID       uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Type             LineType   `json:"type" gorm:"not null"`
+	Name             string     `json:"name" gorm:"not null"`
+	Source           Source     `json:"source" gorm:"not null;default:'MANUAL'"`
+	CategoryID       *uuid.UUID `json:"categoryId,omitempty" gorm:"type:uuid"` // Product category of PRODUCT_CATEGORY lines
+	Amount           int64      `json:"amount" gorm:"not null"`                // Budgeted amount in cents
+	TolerancePercent int        `json:"tolerancePercent" gorm:"not null;default:10"`
+	PeriodStart      *time.Time `json:"periodStart,omitempty"` // Defaults to the start of the first festival day
+	PeriodEnd        *time.Time `json:"periodEnd,omitempty"`   // Defaults to the end of the last festival day
+	Notes            string     `json:"notes,omitempty"`
+	AlertedStatus    Status     `json:"-"` // Status last alerted, cleared once the line is back on track
+	CreatedBy        *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+func (Line) TableName() string {
+	return "budget_lines"
+}
+
+// Entry is an actual recorded by the organizer on a MANUAL line, e.g. an invoice
+type Entry struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null"`
+	LineID      uuid.UUID  `json:"lineId" gorm:"type:uuid;not null;index"`
+	Amount      int64      `json:"amount" gorm:"not null"` // In cents, negative for corrections
+	Description string     `json:"description,omitempty"`
+	IncurredAt  time.Time  `json:"incurredAt" gorm:"not null"`
+	CreatedBy   *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func (Entry) TableName() string {
+	return "budget_entries"
+}
+
+// FestivalInfo is what budgets need of a festival
+type FestivalInfo struct {
+	ID        uuid.UUID
+	Name      string
+	StartDate time.Time
+	EndDate   time.Time
+	Timezone  string
+}
+
+// CreateLineRequest represents the request to add a budget line
+type CreateLineRequest struct {
+	Type             LineType   `json:"type" binding:"required"`
+	Name             string     `json:"name" binding:"required,max=100"`
+	Source           Source     `json:"source"` // Defaults to MANUAL
+	CategoryID       *uuid.UUID `json:"categoryId,omitempty"`
+	Amount           int64      `json:"amount" binding:"min=0"`
+	TolerancePercent *int       `json:"tolerancePercent,omitempty" binding:"omitempty,min=0,max=100"`
+	PeriodStart      *time.Time `json:"periodStart,omitempty"`
+	PeriodEnd        *time.Time `json:"periodEnd,omitempty"`
+	Notes            string     `json:"notes" binding:"max=500"`
+}
+
+// UpdateLineRequest represents the request to update a budget line. The period is
+// reset to the festival days with a zero time.
+type UpdateLineRequest struct {
+	Name             *string    `json:"name,omitempty" binding:"omitempty,max=100"`
+	Source           *Source    `json:"source,omitempty"`
+	CategoryID       *uuid.UUID `json:"categoryId,omitempty"`
+	Amount           *int64     `json:"amount,omitempty" binding:"omitempty,min=0"`
+	TolerancePercent *int       `json:"tolerancePercent,omitempty" binding:"omitempty,min=0,max=100"`
+	PeriodStart      *time.Time `json:"periodStart,omitempty"`
+	PeriodEnd        *time.Time `json:"periodEnd,omitempty"`
+	Notes            *string    `json:"notes,omitempty" binding:"omitempty,max=500"`
+}
+
+// CreateEntryRequest represents the request to record an actual on a MANUAL line
+type CreateEntryRequest struct {
+	Amount      int64      `json:"amount"`
+	Description string     `json:"description" binding:"max=255"`
+	IncurredAt  *time.Time `json:"incurredAt,omitempty"` // Defaults to now
+}
+
+// LineReport is a budget line with its actuals and forecast
+type LineReport struct {
+	Line
+	TrackedFrom     time.Time `json:"trackedFrom"` // Effective period of the line
+	TrackedUntil    time.Time `json:"trackedUntil"`
+	Actual          int64     `json:"actual"`
+	Forecast        int64     `json:"forecast"`                  // Actual projected to the end of the period at the current run-rate
+	RunRatePerDay   int64     `json:"runRatePerDay"`             // Actual per day elapsed in the period
+	Variance        int64     `json:"variance"`                  // Forecast minus budget
+	ActualPercent   *float64  `json:"actualPercent,omitempty"`   // Actual against budget, nil without budget
+	ForecastPercent *float64  `json:"forecastPercent,omitempty"` // Forecast against budget, nil without budget
+	Progress        float64   `json:"progress"`                  // Share of the period elapsed, 0 to 1
+	Status          Status    `json:"status"`
+}
+
+// Totals sums the lines of one type
+type Totals struct {
+	Budget   int64 `json:"budget"`
+	Actual   int64 `json:"actual"`
+	Forecast int64 `json:"forecast"`
+	OffTrack int   `json:"offTrack"` // Lines trending to miss beyond their tolerance
+}
+
+// Report tracks the budget of a festival against its actuals
+type Report struct {
+	FestivalID  uuid.UUID    `json:"festivalId"`
+	Revenue     Totals       `json:"revenue"`
+	Cost        Totals       `json:"cost"`
+	NetBudget   int64        `json:"netBudget"`   // Revenue minus cost budgets
+	NetForecast int64        `json:"netForecast"` // Revenue minus cost forecasts
+	Lines       []LineReport `json:"lines"`
+	GeneratedAt time.Time    `json:"generatedAt"`
+}
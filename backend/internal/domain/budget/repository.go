@@ -0,0 +1,254 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateLine(ctx context.Context, line *Line) error
+	GetLine(ctx context.Context, festivalID, id uuid.UUID) (*Line, error)
+	ListLines(ctx context.Context, festivalID uuid.UUID) ([]Line, error)
+	UpdateLine(ctx context.Context, line *Line) error
+	DeleteLine(ctx context.Context, id uuid.UUID) error
+	MarkAlerted(ctx context.Context, id uuid.UUID, status Status) (bool, error)
+
+	CreateEntry(ctx context.Context, entry *Entry) error
+	GetEntry(ctx context.Context, lineID, id uuid.UUID) (*Entry, error)
+	ListEntries(ctx context.Context, lineID uuid.UUID) ([]Entry, error)
+	DeleteEntry(ctx context.Context, id uuid.UUID) error
+
+	GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error)
+	ProductCategoryExists(ctx context.Context, festivalID, categoryID uuid.UUID) (bool, error)
+	ListFestivalsWithBudgets(ctx context.Context) ([]uuid.UUID, error)
+	GetActual(ctx context.Context, line *Line, from, to time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateLine(ctx context.Context, line *Line) error {
+	if err := r.db.WithContext(ctx).Create(line).Error; err != nil {
+		return fmt.Errorf("failed to create budget line: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetLine(ctx context.Context, festivalID, id uuid.UUID) (*Line, error) {
+	var line Line
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&line).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get budget line: %w", err)
+	}
+	return &line, nil
+}
+
+func (r *repository) ListLines(ctx context.Context, festivalID uuid.UUID) ([]Line, error) {
+	var lines []Line
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ?", festivalID).
+		Order("type DESC, name ASC").
+		Find(&lines).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budget lines: %w", err)
+	}
+	return lines, nil
+}
+
+func (r *repository) UpdateLine(ctx context.Context, line *Line) error {
+	if err := r.db.WithContext(ctx).Save(line).Error; err != nil {
+		return fmt.Errorf("failed to update budget line: %w", err)
+	}
+	return nil
+}
+
+// DeleteLine deletes a line with its entries
+func (r *repository) DeleteLine(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("line_id = ?", id).Delete(&Entry{}).Error; err != nil {
+			return fmt.Errorf("failed to delete budget entries: %w", err)
+		}
+		if err := tx.Delete(&Line{}, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to delete budget line: %w", err)
+		}
+		return nil
+	})
+}
+
+// MarkAlerted records the status last alerted for a line and reports whether it changed,
+// so that only one API instance alerts on a change
+func (r *repository) MarkAlerted(ctx context.Context, id uuid.UUID, status Status) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&Line{}).
+		Where("id = ? AND alerted_status <> ?", id, status).
+		UpdateColumn("alerted_status", status)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark budget line alerted: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) CreateEntry(ctx context.Context, entry *Entry) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create budget entry: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetEntry(ctx context.Context, lineID, id uuid.UUID) (*Entry, error) {
+	var entry Entry
+	err := r.db.WithContext(ctx).Where("id = ? AND line_id = ?", id, lineID).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get budget entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func (r *repository) ListEntries(ctx context.Context, lineID uuid.UUID) ([]Entry, error) {
+	var entries []Entry
+	err := r.db.WithContext(ctx).
+		Where("line_id = ?", lineID).
+		Order("incurred_at DESC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budget entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *repository) DeleteEntry(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&Entry{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete budget entry: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	var festivals []FestivalInfo
+	err := r.db.WithContext(ctx).Raw(
+		"SELECT id, name, start_date, end_date, timezone FROM public.festivals WHERE id = ?",
+		festivalID,
+	).Scan(&festivals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(festivals) == 0 {
+		return nil, nil
+	}
+	return &festivals[0], nil
+}
+
+func (r *repository) ProductCategoryExists(ctx context.Context, festivalID, categoryID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.categories WHERE id = ? AND festival_id = ? AND type = 'PRODUCT')",
+		categoryID, festivalID,
+	).Scan(&exists).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check product category: %w", err)
+	}
+	return exists, nil
+}
+
+// ListFestivalsWithBudgets lists the active festivals with at least one budget line
+func (r *repository) ListFestivalsWithBudgets(ctx context.Context) ([]uuid.UUID, error) {
+	var festivalIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT b.festival_id
+		FROM public.budget_lines b
+		INNER JOIN public.festivals f ON f.id = b.festival_id
+		WHERE f.status = 'ACTIVE'`,
+	).Scan(&festivalIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list festivals with budgets: %w", err)
+	}
+	return festivalIDs, nil
+}
+
+// GetActual sums the actuals of a line from from (inclusive) to to (exclusive)
+func (r *repository) GetActual(ctx context.Context, line *Line, from, to time.Time) (int64, error) {
+	var query string
+	args := []interface{}{line.FestivalID, from, to}
+
+	switch line.Source {
+	case SourceManual:
+		query = `
+			SELECT COALESCE(SUM(e.amount), 0)
+			FROM public.budget_entries e
+			WHERE e.line_id = ? AND e.incurred_at >= ? AND e.incurred_at < ?`
+		args = []interface{}{line.ID, from, to}
+	case SourceOrders:
+		query = `
+			SELECT COALESCE(SUM(o.total_amount), 0)
+			FROM public.orders o
+			WHERE o.festival_id = ? AND o.status = 'PAID'
+				AND o.created_at >= ? AND o.created_at < ?`
+	case SourceProductCategory:
+		if line.CategoryID == nil {
+			return 0, ErrCategoryRequired
+		}
+		query = `
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM public.categories WHERE id = ?
+				UNION ALL
+				SELECT c.id FROM public.categories c INNER JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT COALESCE(SUM((item->>'totalPrice')::bigint), 0)
+			FROM public.orders o
+			CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+			INNER JOIN public.products p ON p.id = (item->>'productId')::uuid
+			WHERE o.festival_id = ? AND o.status = 'PAID'
+				AND o.created_at >= ? AND o.created_at < ?
+				AND p.category_id IN (SELECT id FROM subtree)`
+		args = append([]interface{}{*line.CategoryID}, args...)
+	case SourceTickets:
+		query = `
+			SELECT COALESCE(SUM(tt.price), 0)
+			FROM public.tickets t
+			INNER JOIN public.ticket_types tt ON tt.id = t.ticket_type_id
+			WHERE t.festival_id = ? AND t.status <> 'CANCELLED'
+				AND t.created_at >= ? AND t.created_at < ?`
+	case SourceSettlements:
+		query = `
+			SELECT COALESCE(SUM(tr.amount), 0)
+			FROM public.transfers tr
+			WHERE tr.festival_id = ? AND tr.status = 'PAID'
+				AND tr.created_at >= ? AND tr.created_at < ?`
+	case SourcePaymentFees:
+		query = `
+			SELECT COALESCE(SUM(pi.platform_fee), 0)
+			FROM public.payment_intents pi
+			WHERE pi.festival_id = ? AND pi.status = 'SUCCEEDED'
+				AND pi.completed_at >= ? AND pi.completed_at < ?`
+	case SourceRefunds:
+		query = `
+			SELECT COALESCE(SUM(rf.amount), 0)
+			FROM public.refunds rf
+			INNER JOIN public.payment_intents pi ON pi.id = rf.payment_intent_id
+			WHERE pi.festival_id = ? AND rf.status = 'succeeded'
+				AND rf.created_at >= ? AND rf.created_at < ?`
+	default:
+		return 0, ErrInvalidSource
+	}
+
+	var actual int64
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&actual).Error; err != nil {
+		return 0, fmt.Errorf("failed to get budget actual: %w", err)
+	}
+	return actual, nil
+}
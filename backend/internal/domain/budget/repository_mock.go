@@ -0,0 +1,100 @@
+package budget
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateLine(ctx context.Context, line *Line) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetLine(ctx context.Context, festivalID, id uuid.UUID) (*Line, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Line), args.Error(1)
+}
+
+func (m *MockRepository) ListLines(ctx context.Context, festivalID uuid.UUID) ([]Line, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Line), args.Error(1)
+}
+
+func (m *MockRepository) UpdateLine(ctx context.Context, line *Line) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteLine(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MarkAlerted(ctx context.Context, id uuid.UUID, status Status) (bool, error) {
+	args := m.Called(ctx, id, status)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CreateEntry(ctx context.Context, entry *Entry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetEntry(ctx context.Context, lineID, id uuid.UUID) (*Entry, error) {
+	args := m.Called(ctx, lineID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Entry), args.Error(1)
+}
+
+func (m *MockRepository) ListEntries(ctx context.Context, lineID uuid.UUID) ([]Entry, error) {
+	args := m.Called(ctx, lineID)
+	return args.Get(0).([]Entry), args.Error(1)
+}
+
+func (m *MockRepository) DeleteEntry(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*FestivalInfo), args.Error(1)
+}
+
+func (m *MockRepository) ProductCategoryExists(ctx context.Context, festivalID, categoryID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, festivalID, categoryID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ListFestivalsWithBudgets(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetActual(ctx context.Context, line *Line, from, to time.Time) (int64, error) {
+	args := m.Called(ctx, line, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
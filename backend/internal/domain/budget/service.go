@@ -0,0 +1,469 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultEvaluateInterval is how often the budgets of active festivals are checked for
+// lines trending to miss
+const DefaultEvaluateInterval = 15 * time.Minute
+
+// Alerter broadcasts organizer alerts; satisfied by activity.Service
+type Alerter interface {
+	BroadcastAlert(festivalID string, alert *realtime.Alert)
+}
+
+// Service manages festival budgets and tracks them against actuals from orders,
+// tickets, settlements and manual entries
+type Service struct {
+	repo     Repository
+	alerter  Alerter
+	interval time.Duration
+	stop     chan struct{}
+	now      func() time.Time
+}
+
+// NewService creates a budget service
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo:     repo,
+		interval: DefaultEvaluateInterval,
+		stop:     make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// SetAlerter sets the service used to alert organizers about lines trending to miss
+func (s *Service) SetAlerter(alerter Alerter) {
+	s.alerter = alerter
+}
+
+// Start evaluates the budgets of active festivals periodically until Stop is called
+func (s *Service) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+				if err := s.EvaluateAll(ctx); err != nil {
+					log.Error().Err(err).Msg("Failed to evaluate budgets")
+				}
+				cancel()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic evaluation
+func (s *Service) Stop() {
+	close(s.stop)
+}
+
+// CreateLine adds a budget line to a festival
+func (s *Service) CreateLine(ctx context.Context, festivalID uuid.UUID, req CreateLineRequest, userID *uuid.UUID) (*Line, error) {
+	if req.Source == "" {
+		req.Source = SourceManual
+	}
+	tolerance := DefaultTolerancePercent
+	if req.TolerancePercent != nil {
+		tolerance = *req.TolerancePercent
+	}
+
+	now := s.now()
+	line := &Line{
+		ID:               uuid.New(),
+		FestivalID:       festivalID,
+		Type:             req.Type,
+		Name:             req.Name,
+		Source:           req.Source,
+		CategoryID:       req.CategoryID,
+		Amount:           req.Amount,
+		TolerancePercent: tolerance,
+		PeriodStart:      req.PeriodStart,
+		PeriodEnd:        req.PeriodEnd,
+		Notes:            req.Notes,
+		CreatedBy:        userID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if line.Source != SourceProductCategory {
+		line.CategoryID = nil
+	}
+	if err := s.validateLine(ctx, line); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateLine(ctx, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// ListLines lists the budget lines of a festival
+func (s *Service) ListLines(ctx context.Context, festivalID uuid.UUID) ([]Line, error) {
+	return s.repo.ListLines(ctx, festivalID)
+}
+
+// UpdateLine updates a budget line
+func (s *Service) UpdateLine(ctx context.Context, festivalID, lineID uuid.UUID, req UpdateLineRequest) (*Line, error) {
+	line, err := s.getLine(ctx, festivalID, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		line.Name = *req.Name
+	}
+	if req.Source != nil {
+		line.Source = *req.Source
+	}
+	if req.CategoryID != nil {
+		line.CategoryID = req.CategoryID
+	}
+	if req.Amount != nil {
+		line.Amount = *req.Amount
+	}
+	if req.TolerancePercent != nil {
+		line.TolerancePercent = *req.TolerancePercent
+	}
+	if req.PeriodStart != nil {
+		line.PeriodStart = req.PeriodStart
+		if req.PeriodStart.IsZero() {
+			line.PeriodStart = nil
+		}
+	}
+	if req.PeriodEnd != nil {
+		line.PeriodEnd = req.PeriodEnd
+		if req.PeriodEnd.IsZero() {
+			line.PeriodEnd = nil
+		}
+	}
+	if req.Notes != nil {
+		line.Notes = *req.Notes
+	}
+	if line.Source != SourceProductCategory {
+		line.CategoryID = nil
+	}
+	if err := s.validateLine(ctx, line); err != nil {
+		return nil, err
+	}
+
+	line.UpdatedAt = s.now()
+	if err := s.repo.UpdateLine(ctx, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// DeleteLine deletes a budget line with its entries
+func (s *Service) DeleteLine(ctx context.Context, festivalID, lineID uuid.UUID) error {
+	if _, err := s.getLine(ctx, festivalID, lineID); err != nil {
+		return err
+	}
+	return s.repo.DeleteLine(ctx, lineID)
+}
+
+// CreateEntry records an actual on a MANUAL line
+func (s *Service) CreateEntry(ctx context.Context, festivalID, lineID uuid.UUID, req CreateEntryRequest, userID *uuid.UUID) (*Entry, error) {
+	line, err := s.getLine(ctx, festivalID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.Source != SourceManual {
+		return nil, ErrManualEntriesOnly
+	}
+	if req.Amount == 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	now := s.now()
+	incurredAt := now
+	if req.IncurredAt != nil {
+		incurredAt = *req.IncurredAt
+	}
+
+	entry := &Entry{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		LineID:      lineID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		IncurredAt:  incurredAt,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+	}
+	if err := s.repo.CreateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ListEntries lists the entries of a line
+func (s *Service) ListEntries(ctx context.Context, festivalID, lineID uuid.UUID) ([]Entry, error) {
+	if _, err := s.getLine(ctx, festivalID, lineID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListEntries(ctx, lineID)
+}
+
+// DeleteEntry deletes an entry of a line
+func (s *Service) DeleteEntry(ctx context.Context, festivalID, lineID, entryID uuid.UUID) error {
+	if _, err := s.getLine(ctx, festivalID, lineID); err != nil {
+		return err
+	}
+	entry, err := s.repo.GetEntry(ctx, lineID, entryID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return ErrEntryNotFound
+	}
+	return s.repo.DeleteEntry(ctx, entryID)
+}
+
+// GetReport tracks every budget line of a festival against its actuals and forecast
+func (s *Service) GetReport(ctx context.Context, festivalID uuid.UUID) (*Report, error) {
+	festival, err := s.repo.GetFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	lines, err := s.repo.ListLines(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	report := &Report{
+		FestivalID:  festivalID,
+		Lines:       make([]LineReport, 0, len(lines)),
+		GeneratedAt: now,
+	}
+	for _, line := range lines {
+		from, until := linePeriod(&line, festival)
+		end := until
+		if now.Before(end) {
+			end = now
+		}
+
+		var actual int64
+		if end.After(from) {
+			actual, err = s.repo.GetActual(ctx, &line, from, end)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		lineReport := EvaluateLine(line, from, until, actual, now)
+		totals := &report.Revenue
+		if line.Type == LineTypeCost {
+			totals = &report.Cost
+		}
+		totals.Budget += line.Amount
+		totals.Actual += lineReport.Actual
+		totals.Forecast += lineReport.Forecast
+		if lineReport.Status == StatusOffTrack {
+			totals.OffTrack++
+		}
+
+		report.Lines = append(report.Lines, lineReport)
+	}
+	report.NetBudget = report.Revenue.Budget - report.Cost.Budget
+	report.NetForecast = report.Revenue.Forecast - report.Cost.Forecast
+
+	return report, nil
+}
+
+// EvaluateAll checks the budgets of every active festival
+func (s *Service) EvaluateAll(ctx context.Context) error {
+	festivalIDs, err := s.repo.ListFestivalsWithBudgets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, festivalID := range festivalIDs {
+		if err := s.Evaluate(ctx, festivalID); err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to evaluate festival budget")
+		}
+	}
+	return nil
+}
+
+// Evaluate alerts organizers once when a line starts trending to miss its budget, and
+// re-arms the alert once the line is back within its tolerance
+func (s *Service) Evaluate(ctx context.Context, festivalID uuid.UUID) error {
+	report, err := s.GetReport(ctx, festivalID)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range report.Lines {
+		switch {
+		case line.Status == StatusOffTrack && line.Progress >= MinAlertProgress:
+			changed, err := s.repo.MarkAlerted(ctx, line.ID, StatusOffTrack)
+			if err != nil {
+				return err
+			}
+			if changed {
+				s.alert(festivalID, line)
+			}
+		case line.Status != StatusOffTrack && line.AlertedStatus != "":
+			if _, err := s.repo.MarkAlerted(ctx, line.ID, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) alert(festivalID uuid.UUID, line LineReport) {
+	if s.alerter == nil {
+		return
+	}
+
+	message := fmt.Sprintf("Forecast of %s against a budget of %s", formatCents(line.Forecast), formatCents(line.Amount))
+	if line.ForecastPercent != nil {
+		message += fmt.Sprintf(" (%.0f%%)", *line.ForecastPercent)
+	}
+
+	s.alerter.BroadcastAlert(festivalID.String(), &realtime.Alert{
+		ID:        uuid.New().String(),
+		Type:      "warning",
+		Title:     "Budget trending to miss: " + line.Name,
+		Message:   message,
+		ActionURL: fmt.Sprintf("/festivals/%s/budget", festivalID),
+		Timestamp: s.now(),
+	})
+}
+
+// EvaluateLine projects the actual of a line to the end of its period at the current
+// run-rate and compares the forecast with the budget. Revenue misses when the forecast
+// falls short of the budget, cost when it goes over.
+func EvaluateLine(line Line, from, until time.Time, actual int64, now time.Time) LineReport {
+	report := LineReport{
+		Line:         line,
+		TrackedFrom:  from,
+		TrackedUntil: until,
+		Actual:       actual,
+		Forecast:     actual,
+	}
+
+	total := until.Sub(from)
+	switch {
+	case !now.After(from):
+		report.Status = StatusNotStarted
+		return report
+	case !now.Before(until) || total <= 0:
+		report.Progress = 1
+	default:
+		elapsed := now.Sub(from)
+		report.Progress = math.Round(float64(elapsed)/float64(total)*1000) / 1000
+		report.Forecast = actual + int64(float64(actual)*float64(until.Sub(now))/float64(elapsed))
+	}
+
+	elapsedDays := math.Max(now.Sub(from).Hours(), 1) / 24
+	if !now.Before(until) {
+		elapsedDays = math.Max(total.Hours(), 1) / 24
+	}
+	report.RunRatePerDay = int64(float64(actual) / elapsedDays)
+	report.Variance = report.Forecast - line.Amount
+
+	if line.Amount > 0 {
+		actualPercent := percentOf(actual, line.Amount)
+		forecastPercent := percentOf(report.Forecast, line.Amount)
+		report.ActualPercent = &actualPercent
+		report.ForecastPercent = &forecastPercent
+	}
+
+	// Shortfall of the forecast against the budget, as a share of the budget
+	miss := report.Variance
+	if line.Type == LineTypeRevenue {
+		miss = -miss
+	}
+	switch {
+	case miss <= 0:
+		report.Status = StatusOnTrack
+	case line.Amount > 0 && float64(miss) <= float64(line.Amount)*float64(line.TolerancePercent)/100:
+		report.Status = StatusAtRisk
+	default:
+		report.Status = StatusOffTrack
+	}
+
+	return report
+}
+
+// linePeriod returns the period of a line, defaulting to the festival days in the
+// festival timezone
+func linePeriod(line *Line, festival *FestivalInfo) (time.Time, time.Time) {
+	loc := tz.Load(festival.Timezone)
+	from := time.Date(festival.StartDate.Year(), festival.StartDate.Month(), festival.StartDate.Day(), 0, 0, 0, 0, loc)
+	until := time.Date(festival.EndDate.Year(), festival.EndDate.Month(), festival.EndDate.Day()+1, 0, 0, 0, 0, loc)
+	if line.PeriodStart != nil {
+		from = *line.PeriodStart
+	}
+	if line.PeriodEnd != nil {
+		until = *line.PeriodEnd
+	}
+	return from, until
+}
+
+func (s *Service) validateLine(ctx context.Context, line *Line) error {
+	if !line.Type.IsValid() {
+		return ErrInvalidType
+	}
+	if !line.Source.ValidFor(line.Type) {
+		return ErrInvalidSource
+	}
+	if line.PeriodStart != nil && line.PeriodEnd != nil && !line.PeriodEnd.After(*line.PeriodStart) {
+		return ErrInvalidPeriod
+	}
+
+	if line.Source == SourceProductCategory {
+		if line.CategoryID == nil {
+			return ErrCategoryRequired
+		}
+		exists, err := s.repo.ProductCategoryExists(ctx, line.FestivalID, *line.CategoryID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrCategoryNotFound
+		}
+	}
+	return nil
+}
+
+func (s *Service) getLine(ctx context.Context, festivalID, lineID uuid.UUID) (*Line, error) {
+	line, err := s.repo.GetLine(ctx, festivalID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line == nil {
+		return nil, ErrLineNotFound
+	}
+	return line, nil
+}
+
+func percentOf(value, of int64) float64 {
+	return math.Round(float64(value)/float64(of)*1000) / 10
+}
+
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%.2f EUR", float64(cents)/100)
+}
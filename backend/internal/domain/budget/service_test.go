@@ -0,0 +1,254 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAlerter struct {
+	alerts []*realtime.Alert
+}
+
+func (a *fakeAlerter) BroadcastAlert(festivalID string, alert *realtime.Alert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+var testNow = time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+
+// testFestival returns a three-day festival, at noon of its second day by testNow
+func testFestival() *FestivalInfo {
+	return &FestivalInfo{
+		ID:        uuid.New(),
+		Name:      "Summer Festival",
+		StartDate: time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 7, 19, 0, 0, 0, 0, time.UTC),
+		Timezone:  "UTC",
+	}
+}
+
+func newTestService(repo Repository) (*Service, *fakeAlerter) {
+	alerter := &fakeAlerter{}
+	service := NewService(repo)
+	service.SetAlerter(alerter)
+	service.now = func() time.Time { return testNow }
+	return service, alerter
+}
+
+func testLine(festivalID uuid.UUID, lineType LineType, name string, source Source, amount int64) Line {
+	return Line{
+		ID:               uuid.New(),
+		FestivalID:       festivalID,
+		Type:             lineType,
+		Name:             name,
+		Source:           source,
+		Amount:           amount,
+		TolerancePercent: DefaultTolerancePercent,
+	}
+}
+
+// expectActuals serves the lines of a festival for one report, with the actual of each
+// line over the festival days so far
+func expectActuals(mockRepo *MockRepository, festival *FestivalInfo, lines []Line, actuals ...int64) {
+	mockRepo.On("ListLines", mock.Anything, festival.ID).Return(lines, nil).Once()
+	for i, line := range lines {
+		id := line.ID
+		mockRepo.On("GetActual", mock.Anything, mock.MatchedBy(func(l *Line) bool { return l.ID == id }), festival.StartDate, testNow).
+			Return(actuals[i], nil).Once()
+	}
+}
+
+func TestCreateLine(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	festivalID := uuid.New()
+
+	mockRepo.On("CreateLine", mock.Anything, mock.MatchedBy(func(l *Line) bool {
+		return l.FestivalID == festivalID && l.Name == "Security"
+	})).Return(nil).Once()
+	line, err := service.CreateLine(ctx, festivalID, CreateLineRequest{
+		Type:   LineTypeCost,
+		Name:   "Security",
+		Amount: 500000,
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, SourceManual, line.Source)
+	assert.Equal(t, DefaultTolerancePercent, line.TolerancePercent)
+
+	_, err = service.CreateLine(ctx, festivalID, CreateLineRequest{
+		Type: LineTypeCost, Name: "Bar", Source: SourceOrders, Amount: 100,
+	}, nil)
+	assert.ErrorIs(t, err, ErrInvalidSource)
+
+	_, err = service.CreateLine(ctx, festivalID, CreateLineRequest{
+		Type: LineTypeRevenue, Name: "Drinks", Source: SourceProductCategory, Amount: 100,
+	}, nil)
+	assert.ErrorIs(t, err, ErrCategoryRequired)
+
+	otherCategory := uuid.New()
+	mockRepo.On("ProductCategoryExists", mock.Anything, festivalID, otherCategory).Return(false, nil).Once()
+	_, err = service.CreateLine(ctx, festivalID, CreateLineRequest{
+		Type: LineTypeRevenue, Name: "Drinks", Source: SourceProductCategory, CategoryID: &otherCategory, Amount: 100,
+	}, nil)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+	start := time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+	_, err = service.CreateLine(ctx, festivalID, CreateLineRequest{
+		Type: LineTypeCost, Name: "Stage", Amount: 100, PeriodStart: &start, PeriodEnd: &end,
+	}, nil)
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateLine", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEntry(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	festivalID := uuid.New()
+
+	manual := testLine(festivalID, LineTypeCost, "Security", SourceManual, 1000)
+	mockRepo.On("GetLine", mock.Anything, festivalID, manual.ID).Return(&manual, nil)
+	mockRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e *Entry) bool {
+		return e.LineID == manual.ID && e.Amount == 250
+	})).Return(nil).Once()
+	entry, err := service.CreateEntry(ctx, festivalID, manual.ID, CreateEntryRequest{Amount: 250, Description: "Night shift"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testNow, entry.IncurredAt)
+
+	_, err = service.CreateEntry(ctx, festivalID, manual.ID, CreateEntryRequest{}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	orders := testLine(festivalID, LineTypeRevenue, "Bar", SourceOrders, 1000)
+	mockRepo.On("GetLine", mock.Anything, festivalID, orders.ID).Return(&orders, nil)
+	_, err = service.CreateEntry(ctx, festivalID, orders.ID, CreateEntryRequest{Amount: 250}, nil)
+	assert.ErrorIs(t, err, ErrManualEntriesOnly)
+
+	mockRepo.On("GetLine", mock.Anything, festivalID, mock.Anything).Return(nil, nil)
+	_, err = service.CreateEntry(ctx, festivalID, uuid.New(), CreateEntryRequest{Amount: 250}, nil)
+	assert.ErrorIs(t, err, ErrLineNotFound)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateEntry", 1)
+}
+
+func TestEvaluateLine(t *testing.T) {
+	from := time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC)
+	until := from.Add(72 * time.Hour)
+	halfway := from.Add(36 * time.Hour)
+
+	tests := []struct {
+		name     string
+		line     Line
+		actual   int64
+		now      time.Time
+		forecast int64
+		status   Status
+	}{
+		{"revenue on pace", Line{Type: LineTypeRevenue, Amount: 10000, TolerancePercent: 10}, 5000, halfway, 10000, StatusOnTrack},
+		{"revenue within tolerance", Line{Type: LineTypeRevenue, Amount: 10000, TolerancePercent: 10}, 4600, halfway, 9200, StatusAtRisk},
+		{"revenue trending to miss", Line{Type: LineTypeRevenue, Amount: 10000, TolerancePercent: 10}, 3000, halfway, 6000, StatusOffTrack},
+		{"cost over budget", Line{Type: LineTypeCost, Amount: 10000, TolerancePercent: 10}, 6000, halfway, 12000, StatusOffTrack},
+		{"cost under budget", Line{Type: LineTypeCost, Amount: 10000, TolerancePercent: 10}, 3000, halfway, 6000, StatusOnTrack},
+		{"period over", Line{Type: LineTypeRevenue, Amount: 10000, TolerancePercent: 10}, 9500, until.Add(time.Hour), 9500, StatusAtRisk},
+		{"not started", Line{Type: LineTypeRevenue, Amount: 10000, TolerancePercent: 10}, 0, from.Add(-time.Hour), 0, StatusNotStarted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := EvaluateLine(tt.line, from, until, tt.actual, tt.now)
+			assert.Equal(t, tt.forecast, report.Forecast)
+			assert.Equal(t, tt.status, report.Status)
+			if tt.status != StatusNotStarted {
+				assert.Equal(t, tt.forecast-tt.line.Amount, report.Variance)
+			}
+		})
+	}
+
+	report := EvaluateLine(Line{Type: LineTypeRevenue, Amount: 10000}, from, until, 3000, halfway)
+	assert.Equal(t, 0.5, report.Progress)
+	assert.Equal(t, int64(2000), report.RunRatePerDay)
+	require.NotNil(t, report.ForecastPercent)
+	assert.Equal(t, 60.0, *report.ForecastPercent)
+}
+
+func TestGetReport(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	festival := testFestival()
+
+	bar := testLine(festival.ID, LineTypeRevenue, "Bar", SourceOrders, 90000)
+	security := testLine(festival.ID, LineTypeCost, "Security", SourceManual, 20000)
+	mockRepo.On("GetFestival", mock.Anything, festival.ID).Return(festival, nil)
+	expectActuals(mockRepo, festival, []Line{bar, security}, 45000, 10000)
+
+	report, err := service.GetReport(ctx, festival.ID)
+	require.NoError(t, err)
+	require.Len(t, report.Lines, 2)
+
+	// Halfway through the festival days: the bar is on pace, security is over budget
+	assert.Equal(t, int64(90000), report.Revenue.Forecast)
+	assert.Equal(t, int64(20000), report.Cost.Forecast)
+	assert.Equal(t, int64(70000), report.NetBudget)
+	assert.Equal(t, int64(70000), report.NetForecast)
+	assert.Equal(t, 0, report.Revenue.OffTrack)
+
+	// Lines that have not started have no actual yet
+	stage := testLine(festival.ID, LineTypeCost, "Stage", SourceManual, 5000)
+	periodStart := testNow.Add(time.Hour)
+	stage.PeriodStart = &periodStart
+	mockRepo.On("ListLines", mock.Anything, festival.ID).Return([]Line{stage}, nil).Once()
+	report, err = service.GetReport(ctx, festival.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusNotStarted, report.Lines[0].Status)
+	mockRepo.AssertNumberOfCalls(t, "GetActual", 2)
+
+	unknown := uuid.New()
+	mockRepo.On("GetFestival", mock.Anything, unknown).Return(nil, nil)
+	_, err = service.GetReport(ctx, unknown)
+	assert.ErrorIs(t, err, ErrFestivalNotFound)
+}
+
+func TestEvaluateAlertsOnce(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, alerter := newTestService(mockRepo)
+	ctx := context.Background()
+	festival := testFestival()
+
+	bar := testLine(festival.ID, LineTypeRevenue, "Bar", SourceOrders, 90000)
+	mockRepo.On("ListFestivalsWithBudgets", mock.Anything).Return([]uuid.UUID{festival.ID}, nil)
+	mockRepo.On("GetFestival", mock.Anything, festival.ID).Return(festival, nil)
+
+	// The second evaluation finds the alert already sent
+	expectActuals(mockRepo, festival, []Line{bar}, 20000)
+	mockRepo.On("MarkAlerted", mock.Anything, bar.ID, StatusOffTrack).Return(true, nil).Once()
+	require.NoError(t, service.EvaluateAll(ctx))
+	bar.AlertedStatus = StatusOffTrack
+	expectActuals(mockRepo, festival, []Line{bar}, 20000)
+	mockRepo.On("MarkAlerted", mock.Anything, bar.ID, StatusOffTrack).Return(false, nil).Once()
+	require.NoError(t, service.EvaluateAll(ctx))
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "Budget trending to miss: Bar", alerter.alerts[0].Title)
+
+	// Back on track re-arms the alert
+	expectActuals(mockRepo, festival, []Line{bar}, 45000)
+	mockRepo.On("MarkAlerted", mock.Anything, bar.ID, Status("")).Return(true, nil).Once()
+	require.NoError(t, service.Evaluate(ctx, festival.ID))
+	bar.AlertedStatus = ""
+
+	expectActuals(mockRepo, festival, []Line{bar}, 20000)
+	mockRepo.On("MarkAlerted", mock.Anything, bar.ID, StatusOffTrack).Return(true, nil).Once()
+	require.NoError(t, service.Evaluate(ctx, festival.ID))
+	assert.Len(t, alerter.alerts, 2)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,424 @@
+package bundle
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the management of the bundles, the purchases and their
+// refunds, which should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	bundles := r.Group("/bundles")
+	{
+		bundles.GET("", h.ListBundles)
+		bundles.POST("", h.CreateBundle)
+		bundles.PATCH("/:bundleId", h.UpdateBundle)
+	}
+	purchases := r.Group("/bundle-purchases")
+	{
+		purchases.GET("", h.ListPurchases)
+		purchases.GET("/:purchaseId", h.GetPurchase)
+		purchases.POST("/:purchaseId/refund", h.Refund)
+	}
+}
+
+// RegisterAttendeeRoutes registers the bundle shop of the attendee app, open to every
+// authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	r.GET("/bundle-offers", h.ListOffers)
+	r.POST("/bundle-purchases", h.Checkout)
+	r.GET("/me/bundle-purchases", h.ListMyPurchases)
+}
+
+// CreateBundle puts an entry bundle on sale
+// @Summary Create entry bundle
+// @Description Put on sale a ticket of a ticket type together with a wallet credit, paid in one card payment for the price of the ticket plus the credit. The credit is in cents.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateBundleRequest true "Bundle"
+// @Success 201 {object} response.Response{data=Bundle} "Created bundle"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Ticket type not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundles [post]
+func (h *Handler) CreateBundle(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	bundle, err := h.service.CreateBundle(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, bundle)
+}
+
+// UpdateBundle changes a bundle
+// @Summary Update entry bundle
+// @Description Rename a bundle, change its credit or take it off sale. Purchases already started keep the credit they were sold with.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param bundleId path string true "Bundle ID" format(uuid)
+// @Param request body UpdateBundleRequest true "Changes"
+// @Success 200 {object} response.Response{data=Bundle} "Updated bundle"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Bundle not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundles/{bundleId} [patch]
+func (h *Handler) UpdateBundle(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	bundleID, err := uuid.Parse(c.Param("bundleId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid bundle ID", nil)
+		return
+	}
+
+	var req UpdateBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	bundle, err := h.service.UpdateBundle(c.Request.Context(), festivalID, bundleID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, bundle)
+}
+
+// ListBundles lists the bundles of the festival
+// @Summary List entry bundles
+// @Description List the entry bundles of the festival, on sale or not, with their current price
+// @Tags bundles
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Offer} "Bundles"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundles [get]
+func (h *Handler) ListBundles(c *gin.Context) {
+	h.listBundles(c, false)
+}
+
+// ListOffers lists the bundles on sale
+// @Summary List bundle offers
+// @Description List the entry bundles on sale with their price, the ticket and the wallet credit they include
+// @Tags bundles
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Offer} "Bundles on sale"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundle-offers [get]
+func (h *Handler) ListOffers(c *gin.Context) {
+	h.listBundles(c, true)
+}
+
+func (h *Handler) listBundles(c *gin.Context, onSaleOnly bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	offers, err := h.service.ListBundles(c.Request.Context(), festivalID, onSaleOnly)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, offers)
+}
+
+// Checkout starts the purchase of a bundle
+// @Summary Buy entry bundle
+// @Description Start the purchase of a bundle by the calling attendee. The response carries the client secret of the card payment; once it succeeds the ticket is issued and the credit added to the attendee's wallet of the festival, together.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CheckoutRequest true "Bundle"
+// @Success 201 {object} response.Response{data=Checkout} "Purchase waiting for its payment"
+// @Failure 400 {object} response.ErrorResponse "Not on sale or wallet not active"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Bundle not found"
+// @Failure 409 {object} response.ErrorResponse "Sold out"
+// @Failure 503 {object} response.ErrorResponse "Card payments not configured"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundle-purchases [post]
+func (h *Handler) Checkout(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	checkout, err := h.service.Checkout(c.Request.Context(), festivalID, userID, c.GetString("email"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, checkout)
+}
+
+// ListMyPurchases lists the bundles bought by the calling attendee
+// @Summary List my bundle purchases
+// @Description List the entry bundles the calling attendee bought for the festival, latest first
+// @Tags bundles
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Purchase} "Purchases"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/bundle-purchases [get]
+func (h *Handler) ListMyPurchases(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	purchases, err := h.service.ListPurchases(c.Request.Context(), festivalID, PurchaseFilter{UserID: &userID, Limit: 100})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, purchases)
+}
+
+// ListPurchases lists the bundle purchases
+// @Summary List bundle purchases
+// @Description List the bundle purchases of the festival, latest first, with the amounts paid and refunded for the ticket and the wallet credit
+// @Tags bundles
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param bundleId query string false "Bundle ID" format(uuid)
+// @Param status query string false "Status" Enums(PENDING, COMPLETED, FAILED, REFUND_PENDING, REFUNDED)
+// @Param limit query int false "Maximum purchases, 1 to 1000" default(100)
+// @Success 200 {object} response.Response{data=[]Purchase} "Purchases"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundle-purchases [get]
+func (h *Handler) ListPurchases(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	filter := PurchaseFilter{Limit: 100}
+	if raw := c.Query("bundleId"); raw != "" {
+		bundleID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid bundle ID", nil)
+			return
+		}
+		filter.BundleID = &bundleID
+	}
+	if raw := c.Query("status"); raw != "" {
+		status := PurchaseStatus(raw)
+		switch status {
+		case PurchaseStatusPending, PurchaseStatusCompleted, PurchaseStatusFailed,
+			PurchaseStatusRefundPending, PurchaseStatusRefunded:
+			filter.Status = &status
+		default:
+			response.BadRequest(c, "INVALID_STATUS", "Status must be PENDING, COMPLETED, FAILED, REFUND_PENDING or REFUNDED", nil)
+			return
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 1000 {
+			response.BadRequest(c, "INVALID_LIMIT", "Limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	purchases, err := h.service.ListPurchases(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, purchases)
+}
+
+// GetPurchase returns a bundle purchase
+// @Summary Get bundle purchase
+// @Description Get a bundle purchase with its ticket, its wallet credit and its refund
+// @Tags bundles
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param purchaseId path string true "Purchase ID" format(uuid)
+// @Success 200 {object} response.Response{data=Purchase} "Purchase"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Purchase not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundle-purchases/{purchaseId} [get]
+func (h *Handler) GetPurchase(c *gin.Context) {
+	festivalID, purchaseID, ok := purchaseParams(c)
+	if !ok {
+		return
+	}
+
+	purchase, err := h.service.GetPurchase(c.Request.Context(), festivalID, purchaseID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, purchase)
+}
+
+// Refund refunds a bundle purchase
+// @Summary Refund bundle purchase
+// @Description Refund a completed bundle purchase. The ticket is cancelled and goes back on sale, the credit is taken back from the wallet as far as it was not spent, and the card is refunded the ticket plus the credit taken back. When the card refund fails the purchase stays REFUND_PENDING and the refund can be retried.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param purchaseId path string true "Purchase ID" format(uuid)
+// @Param request body RefundRequest false "Reason"
+// @Success 200 {object} response.Response{data=Purchase} "Refunded purchase"
+// @Failure 400 {object} response.ErrorResponse "Purchase not refundable, or ticket used"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Purchase not found"
+// @Failure 503 {object} response.ErrorResponse "Card payments not configured"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/bundle-purchases/{purchaseId}/refund [post]
+func (h *Handler) Refund(c *gin.Context) {
+	festivalID, purchaseID, ok := purchaseParams(c)
+	if !ok {
+		return
+	}
+
+	var req RefundRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	purchase, err := h.service.Refund(c.Request.Context(), festivalID, purchaseID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, purchase)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func purchaseParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	purchaseID, err := uuid.Parse(c.Param("purchaseId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid purchase ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, purchaseID, true
+}
+
+// userParam returns the calling attendee
+func userParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBundleNotFound):
+		response.NotFound(c, "Entry bundle not found")
+	case errors.Is(err, ErrPurchaseNotFound):
+		response.NotFound(c, "Bundle purchase not found")
+	case errors.Is(err, ErrTicketTypeNotFound):
+		response.NotFound(c, "Ticket type not found")
+	case errors.Is(err, ErrBundleInactive):
+		response.BadRequest(c, "NOT_ON_SALE", err.Error(), nil)
+	case errors.Is(err, ErrWalletNotActive):
+		response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+	case errors.Is(err, ErrNotRefundable):
+		response.BadRequest(c, "NOT_REFUNDABLE", err.Error(), nil)
+	case errors.Is(err, ErrTicketNotRefundable):
+		response.BadRequest(c, "TICKET_NOT_REFUNDABLE", err.Error(), nil)
+	case errors.Is(err, ErrTicketUnavailable):
+		response.Conflict(c, "SOLD_OUT", err.Error())
+	case errors.Is(err, ErrPaymentsUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
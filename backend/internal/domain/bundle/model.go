@@ -0,0 +1,152 @@
+package bundle
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bundle errors
+var (
+	ErrBundleNotFound      = errors.New("entry bundle not found")
+	ErrPurchaseNotFound    = errors.New("bundle purchase not found")
+	ErrBundleInactive      = errors.New("entry bundle is not on sale")
+	ErrTicketTypeNotFound  = errors.New("ticket type not found")
+	ErrTicketUnavailable   = errors.New("tickets of the bundle are not available")
+	ErrWalletNotActive     = errors.New("wallet is not active")
+	ErrNotRefundable       = errors.New("only completed bundle purchases can be refunded")
+	ErrTicketNotRefundable = errors.New("the ticket of the bundle was used or transferred; it cannot be refunded")
+	ErrPaymentsUnavailable = errors.New("card payments are not configured")
+)
+
+// Bundle is an entry bundle a festival sells: a ticket of a ticket type and a wallet
+// credit, paid in one payment for the price of the ticket plus the credit
+type Bundle struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID   uuid.UUID `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Name         string    `json:"name" gorm:"not null"`
+	Description  string    `json:"description,omitempty"`
+	TicketTypeID uuid.UUID `json:"ticketTypeId" gorm:"type:uuid;not null"`
+	CreditAmount int64     `json:"creditAmount" gorm:"not null"` // Credited to the wallet of the buyer, in cents
+	Active       bool      `json:"active" gorm:"not null"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (Bundle) TableName() string {
+	return "entry_bundles"
+}
+
+// TicketType is the ticket type of a bundle, as far as bundles are concerned
+type TicketType struct {
+	ID           uuid.UUID
+	FestivalID   uuid.UUID
+	Name         string
+	Price        int64
+	Quantity     *int
+	QuantitySold int
+	Status       string
+}
+
+// Available reports whether a ticket of the type can be sold
+func (t *TicketType) Available() bool {
+	return t.Status == "ACTIVE" && (t.Quantity == nil || t.QuantitySold < *t.Quantity)
+}
+
+// Offer is a bundle on sale with its price
+type Offer struct {
+	Bundle
+	TicketTypeName string `json:"ticketTypeName"`
+	TicketAmount   int64  `json:"ticketAmount"` // Current price of the ticket, in cents
+	Price          int64  `json:"price"`        // Ticket and credit, in cents
+	Available      bool   `json:"available"`
+}
+
+// PurchaseStatus is the state of a bundle purchase
+type PurchaseStatus string
+
+const (
+	PurchaseStatusPending       PurchaseStatus = "PENDING"        // Waiting for the payment
+	PurchaseStatusCompleted     PurchaseStatus = "COMPLETED"      // Ticket issued and wallet credited
+	PurchaseStatusFailed        PurchaseStatus = "FAILED"         // Paid but not fulfilled, e.g. sold out meanwhile; refunded in full
+	PurchaseStatusRefundPending PurchaseStatus = "REFUND_PENDING" // Ticket and credit taken back, card refund to retry
+	PurchaseStatusRefunded      PurchaseStatus = "REFUNDED"
+)
+
+// Purchase is the purchase of a bundle by an attendee. Its ticket and wallet credit are
+// created together when the payment succeeds, and unwound together by a refund.
+type Purchase struct {
+	ID                   uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BundleID             uuid.UUID      `json:"bundleId" gorm:"type:uuid;not null"`
+	FestivalID           uuid.UUID      `json:"festivalId" gorm:"type:uuid;not null;index"`
+	UserID               uuid.UUID      `json:"userId" gorm:"type:uuid;not null"`
+	WalletID             uuid.UUID      `json:"walletId" gorm:"type:uuid;not null"`
+	StripeIntentID       string         `json:"stripeIntentId" gorm:"not null;uniqueIndex"`
+	TicketTypeID         uuid.UUID      `json:"ticketTypeId" gorm:"type:uuid;not null"`
+	TicketID             *uuid.UUID     `json:"ticketId,omitempty" gorm:"type:uuid"`
+	CreditTransactionID  *uuid.UUID     `json:"creditTransactionId,omitempty" gorm:"type:uuid"`
+	TicketAmount         int64          `json:"ticketAmount" gorm:"not null"` // Paid for the ticket, in cents
+	CreditAmount         int64          `json:"creditAmount" gorm:"not null"` // Paid for the wallet credit, in cents
+	Currency             string         `json:"currency" gorm:"not null"`
+	Status               PurchaseStatus `json:"status" gorm:"not null"`
+	RefundedTicketAmount int64          `json:"refundedTicketAmount"`
+	RefundedCreditAmount int64          `json:"refundedCreditAmount"` // At most what was left of the credit in the wallet
+	StripeRefundID       string         `json:"stripeRefundId,omitempty"`
+	FailureReason        string         `json:"failureReason,omitempty"`
+	CompletedAt          *time.Time     `json:"completedAt,omitempty"`
+	RefundedAt           *time.Time     `json:"refundedAt,omitempty"`
+	CreatedAt            time.Time      `json:"createdAt"`
+	UpdatedAt            time.Time      `json:"updatedAt"`
+}
+
+func (Purchase) TableName() string {
+	return "bundle_purchases"
+}
+
+// Amount is what the purchase was paid, in cents
+func (p *Purchase) Amount() int64 {
+	return p.TicketAmount + p.CreditAmount
+}
+
+// PurchaseFilter narrows the listed purchases
+type PurchaseFilter struct {
+	BundleID *uuid.UUID
+	UserID   *uuid.UUID
+	Status   *PurchaseStatus
+	Limit    int
+}
+
+// Checkout is a bundle purchase waiting for its payment, with the client secret the app
+// confirms the payment with
+type Checkout struct {
+	Purchase     Purchase `json:"purchase"`
+	ClientSecret string   `json:"clientSecret"`
+}
+
+// CreateBundleRequest adds an entry bundle
+type CreateBundleRequest struct {
+	Name         string    `json:"name" binding:"required,max=100"`
+	Description  string    `json:"description,omitempty" binding:"max=1000"`
+	TicketTypeID uuid.UUID `json:"ticketTypeId" binding:"required"`
+	CreditAmount int64     `json:"creditAmount" binding:"required,min=100"` // At least 1 EUR, the smallest card payment
+}
+
+// UpdateBundleRequest changes a bundle; a new credit applies to the purchases started
+// afterwards
+type UpdateBundleRequest struct {
+	Name         *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Description  *string `json:"description,omitempty" binding:"omitempty,max=1000"`
+	CreditAmount *int64  `json:"creditAmount,omitempty" binding:"omitempty,min=100"`
+	Active       *bool   `json:"active,omitempty"`
+}
+
+// CheckoutRequest starts the purchase of a bundle by the signed-in attendee
+type CheckoutRequest struct {
+	BundleID uuid.UUID `json:"bundleId" binding:"required"`
+}
+
+// RefundRequest refunds a bundle purchase
+type RefundRequest struct {
+	Reason string `json:"reason,omitempty" binding:"max=255"`
+}
@@ -0,0 +1,364 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateBundle(ctx context.Context, bundle *Bundle) error
+	UpdateBundle(ctx context.Context, bundle *Bundle) error
+	GetBundle(ctx context.Context, festivalID, id uuid.UUID) (*Bundle, error)
+	ListBundles(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Bundle, error)
+	// GetTicketTypes returns the ticket types of the festival with one of the IDs
+	GetTicketTypes(ctx context.Context, festivalID uuid.UUID, ids []uuid.UUID) ([]TicketType, error)
+
+	CreatePurchase(ctx context.Context, purchase *Purchase) error
+	UpdatePurchase(ctx context.Context, purchase *Purchase) error
+	GetPurchase(ctx context.Context, festivalID, id uuid.UUID) (*Purchase, error)
+	GetPurchaseByIntent(ctx context.Context, stripeIntentID string) (*Purchase, error)
+	ListPurchases(ctx context.Context, festivalID uuid.UUID, filter PurchaseFilter) ([]Purchase, error)
+
+	// Fulfill issues the ticket of a pending purchase and credits its wallet in one
+	// transaction, and marks it completed. A purchase no longer pending is left as it is
+	// and reloaded. It returns ErrTicketUnavailable when the ticket type sold out or was
+	// closed meanwhile, and ErrWalletNotActive when the wallet cannot be credited.
+	Fulfill(ctx context.Context, purchase *Purchase, ticketCode string) error
+	// Unwind cancels the ticket of a completed purchase and takes its credit back from
+	// the wallet in one transaction, as far as the balance allows, and marks the purchase
+	// waiting for its card refund. It returns ErrNotRefundable when the purchase is no
+	// longer completed and ErrTicketNotRefundable when the ticket was used or transferred.
+	Unwind(ctx context.Context, purchase *Purchase, description string) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateBundle(ctx context.Context, bundle *Bundle) error {
+	if err := r.db.WithContext(ctx).Create(bundle).Error; err != nil {
+		return fmt.Errorf("failed to create entry bundle: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateBundle(ctx context.Context, bundle *Bundle) error {
+	if err := r.db.WithContext(ctx).Save(bundle).Error; err != nil {
+		return fmt.Errorf("failed to update entry bundle: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetBundle(ctx context.Context, festivalID, id uuid.UUID) (*Bundle, error) {
+	var bundle Bundle
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&bundle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get entry bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+func (r *repository) ListBundles(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Bundle, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if activeOnly {
+		query = query.Where("active")
+	}
+	var bundles []Bundle
+	if err := query.Order("name").Find(&bundles).Error; err != nil {
+		return nil, fmt.Errorf("failed to list entry bundles: %w", err)
+	}
+	return bundles, nil
+}
+
+func (r *repository) GetTicketTypes(ctx context.Context, festivalID uuid.UUID, ids []uuid.UUID) ([]TicketType, error) {
+	var types []TicketType
+	if len(ids) == 0 {
+		return types, nil
+	}
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT id, festival_id, name, price, quantity, quantity_sold, status
+			FROM ticket_types WHERE festival_id = ? AND id IN (?)`, festivalID, ids).
+		Scan(&types).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket types: %w", err)
+	}
+	return types, nil
+}
+
+func (r *repository) CreatePurchase(ctx context.Context, purchase *Purchase) error {
+	if err := r.db.WithContext(ctx).Create(purchase).Error; err != nil {
+		return fmt.Errorf("failed to create bundle purchase: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdatePurchase(ctx context.Context, purchase *Purchase) error {
+	if err := r.db.WithContext(ctx).Save(purchase).Error; err != nil {
+		return fmt.Errorf("failed to update bundle purchase: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetPurchase(ctx context.Context, festivalID, id uuid.UUID) (*Purchase, error) {
+	var purchase Purchase
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&purchase).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bundle purchase: %w", err)
+	}
+	return &purchase, nil
+}
+
+func (r *repository) GetPurchaseByIntent(ctx context.Context, stripeIntentID string) (*Purchase, error) {
+	var purchase Purchase
+	err := r.db.WithContext(ctx).Where("stripe_intent_id = ?", stripeIntentID).First(&purchase).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bundle purchase: %w", err)
+	}
+	return &purchase, nil
+}
+
+func (r *repository) ListPurchases(ctx context.Context, festivalID uuid.UUID, filter PurchaseFilter) ([]Purchase, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if filter.BundleID != nil {
+		query = query.Where("bundle_id = ?", *filter.BundleID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	var purchases []Purchase
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Find(&purchases).Error; err != nil {
+		return nil, fmt.Errorf("failed to list bundle purchases: %w", err)
+	}
+	return purchases, nil
+}
+
+func (r *repository) Fulfill(ctx context.Context, purchase *Purchase, ticketCode string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockPurchase(tx, purchase.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != PurchaseStatusPending {
+			*purchase = *locked
+			return nil
+		}
+
+		var ticketType TicketType
+		err = tx.Raw(`SELECT id, festival_id, name, price, quantity, quantity_sold, status
+			FROM ticket_types WHERE id = ? FOR UPDATE`, locked.TicketTypeID).
+			Scan(&ticketType).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock ticket type: %w", err)
+		}
+		if ticketType.ID == uuid.Nil || !ticketType.Available() {
+			return ErrTicketUnavailable
+		}
+
+		var w wallet.Wallet
+		if err := tx.Raw("SELECT * FROM wallets WHERE id = ? FOR UPDATE", locked.WalletID).Scan(&w).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+		if w.ID == uuid.Nil || w.Status != wallet.WalletStatusActive {
+			return ErrWalletNotActive
+		}
+
+		now := time.Now()
+		ticketID := uuid.New()
+		err = tx.Exec(`INSERT INTO tickets (id, ticket_type_id, festival_id, user_id, code, holder_email, status, metadata, created_at, updated_at)
+			SELECT ?, ?, ?, ?, ?, email, 'VALID', jsonb_build_object('purchaseDate', ?::text, 'paymentRef', ?::text), ?, ?
+			FROM users WHERE id = ?`,
+			ticketID, locked.TicketTypeID, locked.FestivalID, locked.UserID, ticketCode,
+			now.Format(time.RFC3339), locked.StripeIntentID, now, now, locked.UserID).Error
+		if err != nil {
+			return fmt.Errorf("failed to create ticket: %w", err)
+		}
+
+		sold := ticketType.QuantitySold + 1
+		status := ticketType.Status
+		if ticketType.Quantity != nil && sold >= *ticketType.Quantity {
+			status = "SOLD_OUT"
+		}
+		err = tx.Exec("UPDATE ticket_types SET quantity_sold = ?, status = ?, updated_at = ? WHERE id = ?",
+			sold, status, now, ticketType.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to update quantity sold: %w", err)
+		}
+
+		credit := &wallet.Transaction{
+			ID:            uuid.New(),
+			WalletID:      w.ID,
+			Type:          wallet.TransactionTypeTopUp,
+			Amount:        locked.CreditAmount,
+			BalanceBefore: w.Balance,
+			BalanceAfter:  w.Balance + locked.CreditAmount,
+			Reference:     locked.StripeIntentID,
+			Metadata: wallet.TransactionMeta{
+				Description:   "Entry bundle credit",
+				PaymentMethod: "stripe",
+			},
+			Status:    wallet.TransactionStatusCompleted,
+			CreatedAt: now,
+		}
+		if err := moveBalance(tx, &w, credit); err != nil {
+			return err
+		}
+
+		locked.Status = PurchaseStatusCompleted
+		locked.TicketID = &ticketID
+		locked.CreditTransactionID = &credit.ID
+		locked.CompletedAt = &now
+		locked.UpdatedAt = now
+		if err := tx.Save(locked).Error; err != nil {
+			return fmt.Errorf("failed to complete bundle purchase: %w", err)
+		}
+		*purchase = *locked
+		return nil
+	})
+}
+
+func (r *repository) Unwind(ctx context.Context, purchase *Purchase, description string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockPurchase(tx, purchase.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != PurchaseStatusCompleted {
+			return ErrNotRefundable
+		}
+		now := time.Now()
+
+		if locked.TicketID != nil {
+			var ticket struct {
+				ID           uuid.UUID
+				TicketTypeID uuid.UUID
+				Status       string
+			}
+			err := tx.Raw("SELECT id, ticket_type_id, status FROM tickets WHERE id = ? FOR UPDATE", *locked.TicketID).
+				Scan(&ticket).Error
+			if err != nil {
+				return fmt.Errorf("failed to lock ticket: %w", err)
+			}
+			switch ticket.Status {
+			case "VALID":
+				if err := tx.Exec("UPDATE tickets SET status = 'CANCELLED', updated_at = ? WHERE id = ?", now, ticket.ID).Error; err != nil {
+					return fmt.Errorf("failed to cancel ticket: %w", err)
+				}
+				// The ticket goes back on sale
+				err = tx.Exec(`UPDATE ticket_types
+					SET quantity_sold = GREATEST(quantity_sold - 1, 0),
+						status = CASE WHEN status = 'SOLD_OUT' THEN 'ACTIVE' ELSE status END,
+						updated_at = ?
+					WHERE id = ?`, now, ticket.TicketTypeID).Error
+				if err != nil {
+					return fmt.Errorf("failed to release ticket: %w", err)
+				}
+			case "CANCELLED", "":
+				// Cancelled or deleted meanwhile, it was not refunded then
+			default:
+				return ErrTicketNotRefundable
+			}
+		}
+
+		var w wallet.Wallet
+		if err := tx.Raw("SELECT * FROM wallets WHERE id = ? FOR UPDATE", locked.WalletID).Scan(&w).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+		refundedCredit := creditToTakeBack(locked.CreditAmount, w.Balance)
+		if refundedCredit > 0 {
+			debit := &wallet.Transaction{
+				ID:            uuid.New(),
+				WalletID:      w.ID,
+				Type:          wallet.TransactionTypeCashOut,
+				Amount:        -refundedCredit,
+				BalanceBefore: w.Balance,
+				BalanceAfter:  w.Balance - refundedCredit,
+				Reference:     locked.StripeIntentID,
+				Metadata: wallet.TransactionMeta{
+					Description:   description,
+					PaymentMethod: "card",
+				},
+				Status:    wallet.TransactionStatusCompleted,
+				CreatedAt: now,
+			}
+			if err := moveBalance(tx, &w, debit); err != nil {
+				return err
+			}
+		}
+
+		locked.Status = PurchaseStatusRefundPending
+		locked.RefundedTicketAmount = locked.TicketAmount
+		locked.RefundedCreditAmount = refundedCredit
+		locked.UpdatedAt = now
+		if err := tx.Save(locked).Error; err != nil {
+			return fmt.Errorf("failed to update bundle purchase: %w", err)
+		}
+		*purchase = *locked
+		return nil
+	})
+}
+
+// lockPurchase locks a purchase for the rest of the transaction
+func lockPurchase(tx *gorm.DB, id uuid.UUID) (*Purchase, error) {
+	var purchase Purchase
+	if err := tx.Raw("SELECT * FROM bundle_purchases WHERE id = ? FOR UPDATE", id).Scan(&purchase).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock bundle purchase: %w", err)
+	}
+	if purchase.ID == uuid.Nil {
+		return nil, ErrPurchaseNotFound
+	}
+	return &purchase, nil
+}
+
+// moveBalance applies a transaction to a wallet locked by the transaction and records it
+func moveBalance(tx *gorm.DB, w *wallet.Wallet, txData *wallet.Transaction) error {
+	result := tx.Model(&wallet.Wallet{}).
+		Where("id = ? AND balance = ?", w.ID, w.Balance).
+		Updates(map[string]interface{}{
+			"balance":    txData.BalanceAfter,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update balance: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("concurrent modification detected, please retry")
+	}
+	if err := tx.Create(txData).Error; err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return nil
+}
+
+// creditToTakeBack is the part of a bundle credit a refund takes back from the wallet:
+// all of it, or what is left when the attendee spent some of it
+func creditToTakeBack(credit, balance int64) int64 {
+	if balance <= 0 {
+		return 0
+	}
+	if balance < credit {
+		return balance
+	}
+	return credit
+}
@@ -0,0 +1,89 @@
+package bundle
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateBundle(ctx context.Context, bundle *Bundle) error {
+	args := m.Called(ctx, bundle)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateBundle(ctx context.Context, bundle *Bundle) error {
+	args := m.Called(ctx, bundle)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetBundle(ctx context.Context, festivalID, id uuid.UUID) (*Bundle, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Bundle), args.Error(1)
+}
+
+func (m *MockRepository) ListBundles(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Bundle, error) {
+	args := m.Called(ctx, festivalID, activeOnly)
+	return args.Get(0).([]Bundle), args.Error(1)
+}
+
+func (m *MockRepository) GetTicketTypes(ctx context.Context, festivalID uuid.UUID, ids []uuid.UUID) ([]TicketType, error) {
+	args := m.Called(ctx, festivalID, ids)
+	return args.Get(0).([]TicketType), args.Error(1)
+}
+
+func (m *MockRepository) CreatePurchase(ctx context.Context, purchase *Purchase) error {
+	args := m.Called(ctx, purchase)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdatePurchase(ctx context.Context, purchase *Purchase) error {
+	args := m.Called(ctx, purchase)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPurchase(ctx context.Context, festivalID, id uuid.UUID) (*Purchase, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Purchase), args.Error(1)
+}
+
+func (m *MockRepository) GetPurchaseByIntent(ctx context.Context, stripeIntentID string) (*Purchase, error) {
+	args := m.Called(ctx, stripeIntentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Purchase), args.Error(1)
+}
+
+func (m *MockRepository) ListPurchases(ctx context.Context, festivalID uuid.UUID, filter PurchaseFilter) ([]Purchase, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Purchase), args.Error(1)
+}
+
+func (m *MockRepository) Fulfill(ctx context.Context, purchase *Purchase, ticketCode string) error {
+	args := m.Called(ctx, purchase, ticketCode)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Unwind(ctx context.Context, purchase *Purchase, description string) error {
+	args := m.Called(ctx, purchase, description)
+	return args.Error(0)
+}
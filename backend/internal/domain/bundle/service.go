@@ -0,0 +1,394 @@
+package bundle
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/rs/zerolog/log"
+)
+
+// Wallets finds the wallet a bundle credits, satisfied by *wallet.Service
+type Wallets interface {
+	GetOrCreateWallet(ctx context.Context, userID, festivalID uuid.UUID) (*wallet.Wallet, error)
+}
+
+// PaymentGateway charges bundles by card and refunds them, satisfied by *payment.Service.
+// The payment of a bundle records how much of it pays for the ticket and how much for the
+// wallet credit.
+type PaymentGateway interface {
+	CreateBundlePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, ticketAmount, creditAmount int64, email string, metadata map[string]string) (stripeIntentID, clientSecret string, err error)
+	RefundBundlePayment(ctx context.Context, stripeIntentID string, amount int64, reason string) (stripeRefundID string, err error)
+}
+
+// AuditLogger records the refunds of bundles, satisfied by *audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service sells entry bundles: a ticket and a wallet credit paid in one card payment.
+// Both are created in one database transaction once the payment succeeds, and a refund
+// takes both back before refunding the card, so ticketing and cashless never disagree
+// on what a bundle paid for.
+type Service struct {
+	repo     Repository
+	wallets  Wallets
+	payments PaymentGateway
+	audit    AuditLogger
+	now      func() time.Time
+}
+
+// NewService creates the bundle service
+func NewService(repo Repository, wallets Wallets) *Service {
+	return &Service{
+		repo:    repo,
+		wallets: wallets,
+		now:     time.Now,
+	}
+}
+
+// SetPaymentGateway charges and refunds bundles by card; without it bundles cannot be
+// bought
+func (s *Service) SetPaymentGateway(payments PaymentGateway) {
+	s.payments = payments
+}
+
+// SetAuditLogger records the refunds in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// CreateBundle adds an entry bundle of a ticket type of the festival
+func (s *Service) CreateBundle(ctx context.Context, festivalID uuid.UUID, req CreateBundleRequest) (*Bundle, error) {
+	if _, err := s.getTicketType(ctx, festivalID, req.TicketTypeID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	bundle := &Bundle{
+		ID:           uuid.New(),
+		FestivalID:   festivalID,
+		Name:         strings.TrimSpace(req.Name),
+		Description:  strings.TrimSpace(req.Description),
+		TicketTypeID: req.TicketTypeID,
+		CreditAmount: req.CreditAmount,
+		Active:       true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.repo.CreateBundle(ctx, bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// UpdateBundle changes a bundle. Taking it off sale does not affect the purchases
+// already paid.
+func (s *Service) UpdateBundle(ctx context.Context, festivalID, id uuid.UUID, req UpdateBundleRequest) (*Bundle, error) {
+	bundle, err := s.getBundle(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		bundle.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		bundle.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.CreditAmount != nil {
+		bundle.CreditAmount = *req.CreditAmount
+	}
+	if req.Active != nil {
+		bundle.Active = *req.Active
+	}
+	bundle.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateBundle(ctx, bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// ListBundles lists the bundles of the festival with their current price; attendees
+// only see the ones on sale
+func (s *Service) ListBundles(ctx context.Context, festivalID uuid.UUID, onSaleOnly bool) ([]Offer, error) {
+	bundles, err := s.repo.ListBundles(ctx, festivalID, onSaleOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(bundles))
+	for _, bundle := range bundles {
+		ids = append(ids, bundle.TicketTypeID)
+	}
+	types, err := s.repo.GetTicketTypes(ctx, festivalID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]TicketType, len(types))
+	for _, t := range types {
+		byID[t.ID] = t
+	}
+
+	offers := make([]Offer, 0, len(bundles))
+	for _, bundle := range bundles {
+		t, ok := byID[bundle.TicketTypeID]
+		if !ok {
+			continue
+		}
+		offers = append(offers, Offer{
+			Bundle:         bundle,
+			TicketTypeName: t.Name,
+			TicketAmount:   t.Price,
+			Price:          t.Price + bundle.CreditAmount,
+			Available:      bundle.Active && t.Available(),
+		})
+	}
+	return offers, nil
+}
+
+// Checkout starts the purchase of a bundle by an attendee: it creates the card payment
+// of the ticket and the credit, which the app confirms with the client secret. The
+// ticket and the credit are created once the payment succeeds.
+func (s *Service) Checkout(ctx context.Context, festivalID, userID uuid.UUID, email string, req CheckoutRequest) (*Checkout, error) {
+	if s.payments == nil {
+		return nil, ErrPaymentsUnavailable
+	}
+	bundle, err := s.getBundle(ctx, festivalID, req.BundleID)
+	if err != nil {
+		return nil, err
+	}
+	if !bundle.Active {
+		return nil, ErrBundleInactive
+	}
+	ticketType, err := s.getTicketType(ctx, festivalID, bundle.TicketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if !ticketType.Available() {
+		return nil, ErrTicketUnavailable
+	}
+
+	w, err := s.wallets.GetOrCreateWallet(ctx, userID, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Status != wallet.WalletStatusActive {
+		return nil, ErrWalletNotActive
+	}
+
+	purchaseID := uuid.New()
+	intentID, clientSecret, err := s.payments.CreateBundlePaymentIntent(ctx, festivalID, userID, w.ID,
+		ticketType.Price, bundle.CreditAmount, email, map[string]string{
+			"bundle_id":          bundle.ID.String(),
+			"bundle_purchase_id": purchaseID.String(),
+			"ticket_type_id":     ticketType.ID.String(),
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	purchase := &Purchase{
+		ID:             purchaseID,
+		BundleID:       bundle.ID,
+		FestivalID:     festivalID,
+		UserID:         userID,
+		WalletID:       w.ID,
+		StripeIntentID: intentID,
+		TicketTypeID:   ticketType.ID,
+		TicketAmount:   ticketType.Price,
+		CreditAmount:   bundle.CreditAmount,
+		Currency:       "eur",
+		Status:         PurchaseStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.repo.CreatePurchase(ctx, purchase); err != nil {
+		return nil, err
+	}
+	return &Checkout{Purchase: *purchase, ClientSecret: clientSecret}, nil
+}
+
+// FulfillPayment issues the ticket and credits the wallet of the bundle paid by a
+// succeeded payment intent, once whatever the webhook retries. When the ticket sold out
+// or the wallet was frozen since the checkout, the purchase fails and the payment is
+// refunded in full. An error asks the webhook to be retried.
+func (s *Service) FulfillPayment(ctx context.Context, stripeIntentID string) error {
+	purchase, err := s.repo.GetPurchaseByIntent(ctx, stripeIntentID)
+	if err != nil {
+		return err
+	}
+	if purchase == nil {
+		// The checkout may not have saved the purchase yet
+		return ErrPurchaseNotFound
+	}
+	if purchase.Status != PurchaseStatusPending {
+		return nil
+	}
+
+	code, err := generateTicketCode()
+	if err != nil {
+		return err
+	}
+	err = s.repo.Fulfill(ctx, purchase, code)
+	if errors.Is(err, ErrTicketUnavailable) || errors.Is(err, ErrWalletNotActive) {
+		return s.fail(ctx, purchase, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("purchase_id", purchase.ID.String()).
+		Int64("ticket_amount", purchase.TicketAmount).
+		Int64("credit_amount", purchase.CreditAmount).
+		Msg("Entry bundle fulfilled")
+	return nil
+}
+
+// fail marks a paid purchase that cannot be fulfilled failed and refunds it in full. A
+// refund that fails is retried by refunding the purchase.
+func (s *Service) fail(ctx context.Context, purchase *Purchase, cause error) error {
+	now := s.now()
+	purchase.Status = PurchaseStatusFailed
+	purchase.FailureReason = cause.Error()
+	purchase.RefundedTicketAmount = purchase.TicketAmount
+	purchase.RefundedCreditAmount = purchase.CreditAmount
+	purchase.UpdatedAt = now
+	if err := s.repo.UpdatePurchase(ctx, purchase); err != nil {
+		return err
+	}
+
+	if err := s.refundCard(ctx, purchase, "Entry bundle could not be fulfilled: "+cause.Error()); err != nil {
+		log.Error().Err(err).
+			Str("purchase_id", purchase.ID.String()).
+			Msg("Failed to refund an entry bundle that could not be fulfilled")
+	}
+	return nil
+}
+
+// Refund refunds a bundle purchase: its ticket is cancelled and goes back on sale, its
+// credit is taken back from the wallet as far as it was not spent, and the card is
+// refunded what was taken back. A refund whose card refund failed is retried.
+func (s *Service) Refund(ctx context.Context, festivalID, id uuid.UUID, req RefundRequest, actorID *uuid.UUID) (*Purchase, error) {
+	if s.payments == nil {
+		return nil, ErrPaymentsUnavailable
+	}
+	purchase, err := s.GetPurchase(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "Entry bundle refunded"
+	}
+	switch {
+	case purchase.Status == PurchaseStatusCompleted:
+		if err := s.repo.Unwind(ctx, purchase, reason); err != nil {
+			return nil, err
+		}
+	case purchase.Status == PurchaseStatusRefundPending:
+	case purchase.Status == PurchaseStatusFailed && purchase.StripeRefundID == "":
+	default:
+		return nil, ErrNotRefundable
+	}
+
+	if err := s.refundCard(ctx, purchase, reason); err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     actorID,
+			Action:     audit.ActionTicketRefund,
+			Resource:   "bundle_purchase",
+			ResourceID: purchase.ID.String(),
+			FestivalID: &festivalID,
+			Metadata: map[string]interface{}{
+				"reason":                 reason,
+				"refunded_ticket_amount": purchase.RefundedTicketAmount,
+				"refunded_credit_amount": purchase.RefundedCreditAmount,
+				"stripe_refund_id":       purchase.StripeRefundID,
+			},
+		})
+	}
+	return purchase, nil
+}
+
+// refundCard refunds the card what was taken back from a purchase and saves it
+func (s *Service) refundCard(ctx context.Context, purchase *Purchase, reason string) error {
+	amount := purchase.RefundedTicketAmount + purchase.RefundedCreditAmount
+	if amount > 0 {
+		refundID, err := s.payments.RefundBundlePayment(ctx, purchase.StripeIntentID, amount, reason)
+		if err != nil {
+			return fmt.Errorf("failed to refund the card: %w", err)
+		}
+		purchase.StripeRefundID = refundID
+	}
+
+	now := s.now()
+	if purchase.Status == PurchaseStatusRefundPending {
+		purchase.Status = PurchaseStatusRefunded
+	}
+	purchase.RefundedAt = &now
+	purchase.UpdatedAt = now
+	return s.repo.UpdatePurchase(ctx, purchase)
+}
+
+// GetPurchase returns a purchase of the festival
+func (s *Service) GetPurchase(ctx context.Context, festivalID, id uuid.UUID) (*Purchase, error) {
+	purchase, err := s.repo.GetPurchase(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if purchase == nil {
+		return nil, ErrPurchaseNotFound
+	}
+	return purchase, nil
+}
+
+// ListPurchases lists the purchases of the festival, latest first
+func (s *Service) ListPurchases(ctx context.Context, festivalID uuid.UUID, filter PurchaseFilter) ([]Purchase, error) {
+	return s.repo.ListPurchases(ctx, festivalID, filter)
+}
+
+func (s *Service) getBundle(ctx context.Context, festivalID, id uuid.UUID) (*Bundle, error) {
+	bundle, err := s.repo.GetBundle(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, ErrBundleNotFound
+	}
+	return bundle, nil
+}
+
+func (s *Service) getTicketType(ctx context.Context, festivalID, id uuid.UUID) (*TicketType, error) {
+	types, err := s.repo.GetTicketTypes(ctx, festivalID, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return nil, ErrTicketTypeNotFound
+	}
+	return &types[0], nil
+}
+
+// generateTicketCode generates the QR code value of a ticket, like the ticket service
+func generateTicketCode() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ticket code: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
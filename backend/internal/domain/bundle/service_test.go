@@ -0,0 +1,264 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWallets struct {
+	wallet wallet.Wallet
+}
+
+func (w *fakeWallets) GetOrCreateWallet(ctx context.Context, userID, festivalID uuid.UUID) (*wallet.Wallet, error) {
+	found := w.wallet
+	return &found, nil
+}
+
+type fakeRefund struct {
+	intentID string
+	amount   int64
+}
+
+type fakePayments struct {
+	intents   map[string]int64
+	refunds   []fakeRefund
+	refundErr error
+}
+
+func (p *fakePayments) CreateBundlePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, ticketAmount, creditAmount int64, email string, metadata map[string]string) (string, string, error) {
+	id := "pi_" + uuid.NewString()
+	p.intents[id] = ticketAmount + creditAmount
+	return id, id + "_secret", nil
+}
+
+func (p *fakePayments) RefundBundlePayment(ctx context.Context, stripeIntentID string, amount int64, reason string) (string, error) {
+	if p.refundErr != nil {
+		return "", p.refundErr
+	}
+	p.refunds = append(p.refunds, fakeRefund{intentID: stripeIntentID, amount: amount})
+	return "re_" + uuid.NewString(), nil
+}
+
+type fixture struct {
+	service    *Service
+	mockRepo   *MockRepository
+	payments   *fakePayments
+	festivalID uuid.UUID
+	walletID   uuid.UUID
+	bundle     *Bundle
+	ticketType *TicketType
+}
+
+// newFixture sells a 55 EUR ticket with 20 EUR of credit. The repository returns the
+// bundle from then on.
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{
+		mockRepo:   NewMockRepository(),
+		payments:   &fakePayments{intents: make(map[string]int64)},
+		festivalID: uuid.New(),
+		walletID:   uuid.New(),
+	}
+	f.service = NewService(f.mockRepo, &fakeWallets{wallet: wallet.Wallet{ID: f.walletID, FestivalID: f.festivalID, Status: wallet.WalletStatusActive}})
+	f.service.SetPaymentGateway(f.payments)
+
+	quantity := 10
+	f.ticketType = &TicketType{ID: uuid.New(), FestivalID: f.festivalID, Name: "Weekend", Price: 5500, Quantity: &quantity, Status: "ACTIVE"}
+	f.expectTicketType()
+	f.mockRepo.On("CreateBundle", mock.Anything, mock.AnythingOfType("*bundle.Bundle")).Return(nil).Once()
+
+	bundle, err := f.service.CreateBundle(context.Background(), f.festivalID, CreateBundleRequest{
+		Name:         "Weekend + 20 EUR",
+		TicketTypeID: f.ticketType.ID,
+		CreditAmount: 2000,
+	})
+	require.NoError(t, err)
+	f.bundle = bundle
+	f.mockRepo.On("GetBundle", mock.Anything, f.festivalID, bundle.ID).Return(bundle, nil)
+	return f
+}
+
+// expectTicketType returns the ticket type of the bundle as it is now on the next lookup
+func (f *fixture) expectTicketType() {
+	f.mockRepo.On("GetTicketTypes", mock.Anything, f.festivalID, []uuid.UUID{f.ticketType.ID}).
+		Return([]TicketType{*f.ticketType}, nil).Once()
+}
+
+// checkout starts a purchase of the bundle, which the repository returns from then on
+// by ID and payment intent
+func (f *fixture) checkout(t *testing.T) *Purchase {
+	stored := &Purchase{}
+	f.expectTicketType()
+	f.mockRepo.On("CreatePurchase", mock.Anything, mock.AnythingOfType("*bundle.Purchase")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Purchase) }).
+		Return(nil).Once()
+
+	_, err := f.service.Checkout(context.Background(), f.festivalID, uuid.New(), "sam@example.test", CheckoutRequest{BundleID: f.bundle.ID})
+	require.NoError(t, err)
+
+	f.mockRepo.On("GetPurchase", mock.Anything, f.festivalID, stored.ID).Return(stored, nil).Maybe()
+	f.mockRepo.On("GetPurchaseByIntent", mock.Anything, stored.StripeIntentID).Return(stored, nil).Maybe()
+	return stored
+}
+
+// fulfill pays a purchase, whose ticket is issued and wallet credited in the database
+func (f *fixture) fulfill(t *testing.T, purchase *Purchase) {
+	f.mockRepo.On("Fulfill", mock.Anything, purchase, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			ticketID := uuid.New()
+			purchase.Status = PurchaseStatusCompleted
+			purchase.TicketID = &ticketID
+		}).
+		Return(nil).Once()
+	require.NoError(t, f.service.FulfillPayment(context.Background(), purchase.StripeIntentID))
+}
+
+// expectUnwind cancels the ticket of a purchase and takes credit back from its wallet
+func (f *fixture) expectUnwind(purchase *Purchase, credit int64) {
+	f.mockRepo.On("Unwind", mock.Anything, purchase, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			purchase.Status = PurchaseStatusRefundPending
+			purchase.RefundedTicketAmount = purchase.TicketAmount
+			purchase.RefundedCreditAmount = credit
+		}).
+		Return(nil).Once()
+}
+
+func TestService_CheckoutSplitsThePayment(t *testing.T) {
+	f := newFixture(t)
+
+	f.mockRepo.On("ListBundles", mock.Anything, f.festivalID, true).Return([]Bundle{*f.bundle}, nil).Once()
+	f.expectTicketType()
+	offers, err := f.service.ListBundles(context.Background(), f.festivalID, true)
+	require.NoError(t, err)
+	require.Len(t, offers, 1)
+	assert.Equal(t, int64(7500), offers[0].Price)
+	assert.True(t, offers[0].Available)
+
+	purchase := f.checkout(t)
+	assert.Equal(t, PurchaseStatusPending, purchase.Status)
+	assert.Equal(t, int64(5500), purchase.TicketAmount)
+	assert.Equal(t, int64(2000), purchase.CreditAmount)
+	assert.Equal(t, f.walletID, purchase.WalletID)
+	assert.Equal(t, int64(7500), f.payments.intents[purchase.StripeIntentID])
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_FulfillPaymentIssuesTicketAndCreditOnce(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	purchase := f.checkout(t)
+
+	f.fulfill(t, purchase)
+	// Webhooks are retried
+	require.NoError(t, f.service.FulfillPayment(ctx, purchase.StripeIntentID))
+	f.mockRepo.AssertNumberOfCalls(t, "Fulfill", 1)
+	assert.Equal(t, PurchaseStatusCompleted, purchase.Status)
+	assert.NotNil(t, purchase.TicketID)
+
+	f.mockRepo.On("GetPurchaseByIntent", mock.Anything, "pi_unknown").Return(nil, nil).Once()
+	err := f.service.FulfillPayment(ctx, "pi_unknown")
+	assert.ErrorIs(t, err, ErrPurchaseNotFound)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_FulfillPaymentRefundsWhenSoldOut(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	purchase := f.checkout(t)
+
+	// The last tickets sold between the checkout and the payment
+	f.mockRepo.On("Fulfill", mock.Anything, purchase, mock.AnythingOfType("string")).Return(ErrTicketUnavailable).Once()
+	f.mockRepo.On("UpdatePurchase", mock.Anything, purchase).Return(nil).Twice()
+
+	require.NoError(t, f.service.FulfillPayment(ctx, purchase.StripeIntentID))
+	assert.Equal(t, PurchaseStatusFailed, purchase.Status)
+	assert.Nil(t, purchase.TicketID)
+	assert.NotEmpty(t, purchase.StripeRefundID)
+	assert.Equal(t, []fakeRefund{{intentID: purchase.StripeIntentID, amount: 7500}}, f.payments.refunds)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_RefundUnwindsTicketAndUnspentCredit(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	purchase := f.checkout(t)
+	f.fulfill(t, purchase)
+
+	// 12.50 EUR of the credit was spent at the bar
+	f.expectUnwind(purchase, 750)
+	f.mockRepo.On("UpdatePurchase", mock.Anything, purchase).Return(nil).Once()
+
+	refunded, err := f.service.Refund(ctx, f.festivalID, purchase.ID, RefundRequest{Reason: "Cannot attend"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, PurchaseStatusRefunded, refunded.Status)
+	assert.Equal(t, int64(5500), refunded.RefundedTicketAmount)
+	assert.Equal(t, int64(750), refunded.RefundedCreditAmount)
+	assert.Equal(t, []fakeRefund{{intentID: purchase.StripeIntentID, amount: 6250}}, f.payments.refunds)
+
+	_, err = f.service.Refund(ctx, f.festivalID, purchase.ID, RefundRequest{}, nil)
+	assert.ErrorIs(t, err, ErrNotRefundable)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_RefundRetriesFailedCardRefund(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	purchase := f.checkout(t)
+	f.fulfill(t, purchase)
+	f.expectUnwind(purchase, 2000)
+
+	f.payments.refundErr = errors.New("stripe unavailable")
+	_, err := f.service.Refund(ctx, f.festivalID, purchase.ID, RefundRequest{}, nil)
+	require.Error(t, err)
+	assert.Equal(t, PurchaseStatusRefundPending, purchase.Status)
+	f.mockRepo.AssertNotCalled(t, "UpdatePurchase", mock.Anything, mock.Anything)
+
+	// The ticket and the credit are not taken back twice
+	f.payments.refundErr = nil
+	f.mockRepo.On("UpdatePurchase", mock.Anything, purchase).Return(nil).Once()
+	refunded, err := f.service.Refund(ctx, f.festivalID, purchase.ID, RefundRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, PurchaseStatusRefunded, refunded.Status)
+	assert.Equal(t, []fakeRefund{{intentID: purchase.StripeIntentID, amount: 7500}}, f.payments.refunds)
+	f.mockRepo.AssertNumberOfCalls(t, "Unwind", 1)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_CheckoutRefusesUnavailableBundles(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.ticketType.Status = "SOLD_OUT"
+	f.expectTicketType()
+	_, err := f.service.Checkout(ctx, f.festivalID, uuid.New(), "", CheckoutRequest{BundleID: f.bundle.ID})
+	assert.ErrorIs(t, err, ErrTicketUnavailable)
+
+	inactive := false
+	f.mockRepo.On("UpdateBundle", mock.Anything, mock.MatchedBy(func(b *Bundle) bool {
+		return b.ID == f.bundle.ID && !b.Active
+	})).Return(nil).Once()
+	_, err = f.service.UpdateBundle(ctx, f.festivalID, f.bundle.ID, UpdateBundleRequest{Active: &inactive})
+	require.NoError(t, err)
+	_, err = f.service.Checkout(ctx, f.festivalID, uuid.New(), "", CheckoutRequest{BundleID: f.bundle.ID})
+	assert.ErrorIs(t, err, ErrBundleInactive)
+
+	unknown := uuid.New()
+	f.mockRepo.On("GetBundle", mock.Anything, f.festivalID, unknown).Return(nil, nil).Once()
+	_, err = f.service.Checkout(ctx, f.festivalID, uuid.New(), "", CheckoutRequest{BundleID: unknown})
+	assert.ErrorIs(t, err, ErrBundleNotFound)
+	f.mockRepo.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestCreditToTakeBack(t *testing.T) {
+	assert.Equal(t, int64(2000), creditToTakeBack(2000, 3500))
+	assert.Equal(t, int64(750), creditToTakeBack(2000, 750))
+	assert.Zero(t, creditToTakeBack(2000, 0))
+}
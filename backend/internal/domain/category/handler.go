@@ -0,0 +1,259 @@
+package category
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	categories := r.Group("/categories")
+	{
+		categories.POST("", h.Create)
+		categories.GET("", h.List)
+		categories.GET("/:categoryId", h.GetByID)
+		categories.PATCH("/:categoryId", h.Update)
+		categories.DELETE("/:categoryId", h.Delete)
+	}
+}
+
+// Create creates a new category
+// @Summary Create category
+// @Description Create a stand or product category, optionally below a parent category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateCategoryRequest true "Category data"
+// @Success 201 {object} response.Response{data=CategoryResponse} "Category created"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Slug already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/categories [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	category, err := h.service.Create(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, category.ToResponse())
+}
+
+// List lists the festival categories
+// @Summary List categories
+// @Description Get the festival categories as a flat list or as a tree
+// @Tags categories
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param type query string false "Category type" Enums(STAND, PRODUCT)
+// @Param tree query bool false "Return categories as a tree" default(false)
+// @Param lang query string false "Languages to localize names and descriptions in, before Accept-Language" example(fr-BE)
+// @Success 200 {object} response.Response{data=[]CategoryResponse} "Categories"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/categories [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	categoryType := CategoryType(c.Query("type"))
+	languages := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+
+	if c.Query("tree") == "true" {
+		tree, err := h.service.Tree(c.Request.Context(), festivalID, categoryType)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+		for i := range tree {
+			tree[i].Localize(languages)
+		}
+		response.OK(c, tree)
+		return
+	}
+
+	categories, err := h.service.List(c.Request.Context(), festivalID, categoryType)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	items := make([]CategoryResponse, len(categories))
+	for i, cat := range categories {
+		items[i] = cat.ToResponse()
+		items[i].Localize(languages)
+	}
+
+	response.OK(c, items)
+}
+
+// GetByID gets a category by ID
+// @Summary Get category
+// @Description Get a category by ID
+// @Tags categories
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param categoryId path string true "Category ID" format(uuid)
+// @Param lang query string false "Languages to localize the name and description in, before Accept-Language" example(fr-BE)
+// @Success 200 {object} response.Response{data=CategoryResponse} "Category"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/categories/{categoryId} [get]
+func (h *Handler) GetByID(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid category ID", nil)
+		return
+	}
+
+	category, err := h.service.GetByID(c.Request.Context(), festivalID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := category.ToResponse()
+	resp.Localize(i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language")))
+
+	response.OK(c, resp)
+}
+
+// Update updates a category
+// @Summary Update category
+// @Description Update a category or move it within the category tree
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param categoryId path string true "Category ID" format(uuid)
+// @Param request body UpdateCategoryRequest true "Update data"
+// @Success 200 {object} response.Response{data=CategoryResponse} "Updated category"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
+// @Failure 409 {object} response.ErrorResponse "Slug already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/categories/{categoryId} [patch]
+func (h *Handler) Update(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid category ID", nil)
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	category, err := h.service.Update(c.Request.Context(), festivalID, id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, category.ToResponse())
+}
+
+// Delete deletes a category
+// @Summary Delete category
+// @Description Delete a category without subcategories; assigned stands and products become uncategorized
+// @Tags categories
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param categoryId path string true "Category ID" format(uuid)
+// @Success 204 "Category deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
+// @Failure 409 {object} response.ErrorResponse "Category has subcategories"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/categories/{categoryId} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid category ID", nil)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), festivalID, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrCategoryNotFound):
+		response.NotFound(c, "Category not found")
+	case errors.Is(err, ErrSlugTaken):
+		response.Conflict(c, "SLUG_TAKEN", err.Error())
+	case errors.Is(err, ErrCategoryHasChildren):
+		response.Conflict(c, "CATEGORY_HAS_CHILDREN", err.Error())
+	case errors.Is(err, ErrParentNotFound),
+		errors.Is(err, ErrParentTypeMismatch),
+		errors.Is(err, ErrCategoryCycle),
+		errors.Is(err, ErrMaxDepthExceeded):
+		response.BadRequest(c, "INVALID_PARENT", err.Error(), nil)
+	case i18n.IsInvalidText(err):
+		response.BadRequest(c, "INVALID_TRANSLATIONS", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,166 @@
+package category
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+)
+
+// MaxDepth is the maximum nesting level of the category tree (root = 1)
+const MaxDepth = 4
+
+// Category errors
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrParentNotFound      = errors.New("parent category not found")
+	ErrParentTypeMismatch  = errors.New("parent category must have the same type")
+	ErrCategoryCycle       = errors.New("category cannot be moved below itself")
+	ErrMaxDepthExceeded    = errors.New("category tree is too deep")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrSlugTaken           = errors.New("category slug already exists")
+)
+
+// CategoryType distinguishes the taxonomy a category belongs to
+type CategoryType string
+
+const (
+	CategoryTypeStand   CategoryType = "STAND"
+	CategoryTypeProduct CategoryType = "PRODUCT"
+)
+
+// Category represents a festival-level, hierarchical category for stands or products
+type Category struct {
+	ID                      uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID              uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ParentID                *uuid.UUID   `json:"parentId,omitempty" gorm:"type:uuid;index"`
+	Type                    CategoryType `json:"type" gorm:"not null"`
+	Name                    string       `json:"name" gorm:"not null"`
+	Slug                    string       `json:"slug" gorm:"not null"`
+	Description             string       `json:"description"`
+	NameTranslations        i18n.Text    `json:"nameTranslations,omitempty" gorm:"type:jsonb;default:'{}'"` // Keyed by language tag, including the festival's default locale
+	DescriptionTranslations i18n.Text    `json:"descriptionTranslations,omitempty" gorm:"type:jsonb;default:'{}'"`
+	Icon                    string       `json:"icon,omitempty"`
+	SortOrder               int          `json:"sortOrder" gorm:"default:0"`
+	TaxClass                string       `json:"taxClass,omitempty"` // Default tax class for items in this category
+	Active                  bool         `json:"active" gorm:"default:true"`
+	CreatedAt               time.Time    `json:"createdAt"`
+	UpdatedAt               time.Time    `json:"updatedAt"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+// CreateCategoryRequest represents the request to create a category
+type CreateCategoryRequest struct {
+	ParentID                *uuid.UUID   `json:"parentId,omitempty"`
+	Type                    CategoryType `json:"type" binding:"required,oneof=STAND PRODUCT"`
+	Name                    string       `json:"name" binding:"required"`
+	Slug                    string       `json:"slug"`
+	Description             string       `json:"description"`
+	NameTranslations        i18n.Text    `json:"nameTranslations"` // Must include the festival's default locale, whose text becomes the name
+	DescriptionTranslations i18n.Text    `json:"descriptionTranslations"`
+	Icon                    string       `json:"icon"`
+	SortOrder               int          `json:"sortOrder"`
+	TaxClass                string       `json:"taxClass"`
+}
+
+// UpdateCategoryRequest represents the request to update a category.
+// Set ClearParent to move the category to the root level.
+type UpdateCategoryRequest struct {
+	ParentID                *uuid.UUID `json:"parentId,omitempty"`
+	ClearParent             bool       `json:"clearParent,omitempty"`
+	Name                    *string    `json:"name,omitempty"`
+	Slug                    *string    `json:"slug,omitempty"`
+	Description             *string    `json:"description,omitempty"`
+	NameTranslations        i18n.Text  `json:"nameTranslations,omitempty"` // Replaces all translations; an empty object removes them
+	DescriptionTranslations i18n.Text  `json:"descriptionTranslations,omitempty"`
+	Icon                    *string    `json:"icon,omitempty"`
+	SortOrder               *int       `json:"sortOrder,omitempty"`
+	TaxClass                *string    `json:"taxClass,omitempty"`
+	Active                  *bool      `json:"active,omitempty"`
+}
+
+// CategoryResponse represents the API response for a category
+type CategoryResponse struct {
+	ID                      uuid.UUID          `json:"id"`
+	FestivalID              uuid.UUID          `json:"festivalId"`
+	ParentID                *uuid.UUID         `json:"parentId,omitempty"`
+	Type                    CategoryType       `json:"type"`
+	Name                    string             `json:"name"`
+	Slug                    string             `json:"slug"`
+	Description             string             `json:"description"`
+	NameTranslations        i18n.Text          `json:"nameTranslations,omitempty"`
+	DescriptionTranslations i18n.Text          `json:"descriptionTranslations,omitempty"`
+	Icon                    string             `json:"icon,omitempty"`
+	SortOrder               int                `json:"sortOrder"`
+	TaxClass                string             `json:"taxClass,omitempty"`
+	Active                  bool               `json:"active"`
+	Children                []CategoryResponse `json:"children,omitempty"`
+	CreatedAt               string             `json:"createdAt"`
+	UpdatedAt               string             `json:"updatedAt"`
+}
+
+func (c *Category) ToResponse() CategoryResponse {
+	return CategoryResponse{
+		ID:                      c.ID,
+		FestivalID:              c.FestivalID,
+		ParentID:                c.ParentID,
+		Type:                    c.Type,
+		Name:                    c.Name,
+		Slug:                    c.Slug,
+		Description:             c.Description,
+		NameTranslations:        c.NameTranslations,
+		DescriptionTranslations: c.DescriptionTranslations,
+		Icon:                    c.Icon,
+		SortOrder:               c.SortOrder,
+		TaxClass:                c.TaxClass,
+		Active:                  c.Active,
+		CreatedAt:               c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:               c.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// Localize sets the name and description of the category and its subcategories in the
+// best of the languages asked, keeping the text in the festival's default locale when
+// there is no translation
+func (r *CategoryResponse) Localize(languages []string) {
+	r.Name = r.NameTranslations.Localize(languages, r.Name)
+	r.Description = r.DescriptionTranslations.Localize(languages, r.Description)
+	for i := range r.Children {
+		r.Children[i].Localize(languages)
+	}
+}
+
+// BuildTree arranges a flat list of categories into a tree of responses.
+// Categories whose parent is not in the list are treated as roots.
+func BuildTree(categories []Category) []CategoryResponse {
+	byID := make(map[uuid.UUID]bool, len(categories))
+	children := make(map[uuid.UUID][]Category)
+	var roots []Category
+
+	for _, c := range categories {
+		byID[c.ID] = true
+	}
+	for _, c := range categories {
+		if c.ParentID != nil && byID[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var build func(nodes []Category) []CategoryResponse
+	build = func(nodes []Category) []CategoryResponse {
+		result := make([]CategoryResponse, len(nodes))
+		for i, n := range nodes {
+			result[i] = n.ToResponse()
+			result[i].Children = build(children[n.ID])
+		}
+		return result
+	}
+
+	return build(roots)
+}
@@ -0,0 +1,88 @@
+package category
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, category *Category) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Category, error)
+	GetBySlug(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType, slug string) (*Category, error)
+	ListByFestival(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType) ([]Category, error)
+	CountChildren(ctx context.Context, id uuid.UUID) (int64, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Category, error) {
+	var category Category
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&category).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return &category, nil
+}
+
+func (r *repository) GetBySlug(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType, slug string) (*Category, error) {
+	var category Category
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND type = ? AND slug = ?", festivalID, categoryType, slug).
+		First(&category).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get category by slug: %w", err)
+	}
+	return &category, nil
+}
+
+// ListByFestival lists all categories of a festival; an empty type returns both taxonomies
+func (r *repository) ListByFestival(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType) ([]Category, error) {
+	var categories []Category
+
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if categoryType != "" {
+		query = query.Where("type = ?", categoryType)
+	}
+
+	if err := query.Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (r *repository) CountChildren(ctx context.Context, id uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&Category{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count subcategories: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) Update(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&Category{}).Error
+}
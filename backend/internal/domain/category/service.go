@@ -0,0 +1,319 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// LocaleResolver resolves the language a festival's categories are written in; satisfied by festival.Service
+type LocaleResolver interface {
+	DefaultLocale(ctx context.Context, festivalID uuid.UUID) (string, error)
+}
+
+type Service struct {
+	repo    Repository
+	locales LocaleResolver
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// SetLocaleResolver enables checking translations against the festival's default locale
+func (s *Service) SetLocaleResolver(resolver LocaleResolver) {
+	s.locales = resolver
+}
+
+// festivalLocale returns the default locale of a festival, i18n.Default without a resolver
+func (s *Service) festivalLocale(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	if s.locales == nil {
+		return i18n.Default, nil
+	}
+	return s.locales.DefaultLocale(ctx, festivalID)
+}
+
+// Create creates a new category
+func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, req CreateCategoryRequest) (*Category, error) {
+	if req.ParentID != nil {
+		if err := s.validateParent(ctx, festivalID, req.Type, uuid.Nil, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	locale, err := s.festivalLocale(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	name, nameTranslations, err := i18n.UpdateText(locale, req.Name, nil, nil, req.NameTranslations)
+	if err != nil {
+		return nil, err
+	}
+	description, descriptionTranslations, err := i18n.UpdateText(locale, req.Description, nil, nil, req.DescriptionTranslations)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := req.Slug
+	if slug == "" {
+		slug = slugify(name)
+	}
+	if err := s.ensureSlugAvailable(ctx, festivalID, req.Type, slug, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	category := &Category{
+		ID:                      uuid.New(),
+		FestivalID:              festivalID,
+		ParentID:                req.ParentID,
+		Type:                    req.Type,
+		Name:                    name,
+		Slug:                    slug,
+		Description:             description,
+		NameTranslations:        nameTranslations,
+		DescriptionTranslations: descriptionTranslations,
+		Icon:                    req.Icon,
+		SortOrder:               req.SortOrder,
+		TaxClass:                req.TaxClass,
+		Active:                  true,
+		CreatedAt:               time.Now(),
+		UpdatedAt:               time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetByID gets a category of a festival by ID
+func (s *Service) GetByID(ctx context.Context, festivalID, id uuid.UUID) (*Category, error) {
+	category, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category.FestivalID != festivalID {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// get gets a category by ID, whatever its festival
+func (s *Service) get(ctx context.Context, id uuid.UUID) (*Category, error) {
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// List lists the categories of a festival as a flat list
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType) ([]Category, error) {
+	return s.repo.ListByFestival(ctx, festivalID, categoryType)
+}
+
+// Tree returns the categories of a festival arranged hierarchically
+func (s *Service) Tree(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType) ([]CategoryResponse, error) {
+	categories, err := s.repo.ListByFestival(ctx, festivalID, categoryType)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTree(categories), nil
+}
+
+// Update updates a category of a festival, including moving it within the tree
+func (s *Service) Update(ctx context.Context, festivalID, id uuid.UUID, req UpdateCategoryRequest) (*Category, error) {
+	category, err := s.GetByID(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ClearParent {
+		category.ParentID = nil
+	} else if req.ParentID != nil {
+		if err := s.validateParent(ctx, category.FestivalID, category.Type, category.ID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = req.ParentID
+	}
+
+	if req.Name != nil || req.Description != nil || req.NameTranslations != nil || req.DescriptionTranslations != nil {
+		locale, err := s.festivalLocale(ctx, category.FestivalID)
+		if err != nil {
+			return nil, err
+		}
+		category.Name, category.NameTranslations, err = i18n.UpdateText(locale, category.Name, category.NameTranslations, req.Name, req.NameTranslations)
+		if err != nil {
+			return nil, err
+		}
+		category.Description, category.DescriptionTranslations, err = i18n.UpdateText(locale, category.Description, category.DescriptionTranslations, req.Description, req.DescriptionTranslations)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Slug != nil && *req.Slug != category.Slug {
+		if err := s.ensureSlugAvailable(ctx, category.FestivalID, category.Type, *req.Slug, category.ID); err != nil {
+			return nil, err
+		}
+		category.Slug = *req.Slug
+	}
+	if req.Icon != nil {
+		category.Icon = *req.Icon
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+	if req.TaxClass != nil {
+		category.TaxClass = *req.TaxClass
+	}
+	if req.Active != nil {
+		category.Active = *req.Active
+	}
+
+	category.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	return category, nil
+}
+
+// Delete deletes a leaf category of a festival. Stands and products referencing it
+// become uncategorized.
+func (s *Service) Delete(ctx context.Context, festivalID, id uuid.UUID) error {
+	if _, err := s.GetByID(ctx, festivalID, id); err != nil {
+		return err
+	}
+
+	children, err := s.repo.CountChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrCategoryHasChildren
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+// DescendantIDs returns the ID of a category and of all its subcategories.
+// It is used to filter stands and products by a category and its children.
+func (s *Service) DescendantIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	category, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.ListByFestival(ctx, category.FestivalID, category.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	ids := []uuid.UUID{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// DefaultTaxClass returns the tax class of the category or, if it has none,
+// of its closest ancestor. An empty string means no default is configured.
+func (s *Service) DefaultTaxClass(ctx context.Context, id uuid.UUID) (string, error) {
+	current := &id
+	for depth := 0; current != nil && depth < MaxDepth; depth++ {
+		category, err := s.get(ctx, *current)
+		if err != nil {
+			return "", err
+		}
+		if category.TaxClass != "" {
+			return category.TaxClass, nil
+		}
+		current = category.ParentID
+	}
+	return "", nil
+}
+
+// validateParent checks that parentID can become the parent of category id
+// (uuid.Nil for a new category) without breaking the tree invariants.
+func (s *Service) validateParent(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType, id, parentID uuid.UUID) error {
+	if parentID == id {
+		return ErrCategoryCycle
+	}
+
+	parent, err := s.repo.GetByID(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil || parent.FestivalID != festivalID {
+		return ErrParentNotFound
+	}
+	if parent.Type != categoryType {
+		return ErrParentTypeMismatch
+	}
+
+	// Walk up from the new parent to make sure we neither create a cycle nor exceed the depth
+	depth := 1
+	for ancestor := parent; ancestor.ParentID != nil; depth++ {
+		if *ancestor.ParentID == id {
+			return ErrCategoryCycle
+		}
+		if depth >= MaxDepth {
+			return ErrMaxDepthExceeded
+		}
+		ancestor, err = s.repo.GetByID(ctx, *ancestor.ParentID)
+		if err != nil {
+			return err
+		}
+		if ancestor == nil {
+			break
+		}
+	}
+
+	if depth+1 > MaxDepth {
+		return ErrMaxDepthExceeded
+	}
+	return nil
+}
+
+func (s *Service) ensureSlugAvailable(ctx context.Context, festivalID uuid.UUID, categoryType CategoryType, slug string, excludeID uuid.UUID) error {
+	existing, err := s.repo.GetBySlug(ctx, festivalID, categoryType, slug)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != excludeID {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// slugify converts a string to a URL-friendly slug
+func slugify(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, _ := transform.String(t, s)
+
+	result = strings.ToLower(result)
+	result = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(result, "-")
+
+	return strings.Trim(result, "-")
+}
@@ -0,0 +1,45 @@
+package category
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ScopedToFestival(t *testing.T) {
+	festivalID := uuid.New()
+	otherFestivalID := uuid.New()
+	drinks := &Category{ID: uuid.New(), FestivalID: festivalID, Type: CategoryTypeProduct, Name: "Drinks", Slug: "drinks"}
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetByID", mock.Anything, drinks.ID).Return(drinks, nil)
+	mockRepo.On("CountChildren", mock.Anything, drinks.ID).Return(int64(0), nil)
+	mockRepo.On("Delete", mock.Anything, drinks.ID).Return(nil).Once()
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	category, err := service.GetByID(ctx, festivalID, drinks.ID)
+	require.NoError(t, err)
+	assert.Equal(t, drinks.ID, category.ID)
+
+	// The categories of another festival are not found through its routes
+	_, err = service.GetByID(ctx, otherFestivalID, drinks.ID)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+	name := "Beers"
+	_, err = service.Update(ctx, otherFestivalID, drinks.ID, UpdateCategoryRequest{Name: &name})
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+	assert.Equal(t, "Drinks", drinks.Name)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	err = service.Delete(ctx, otherFestivalID, drinks.ID)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+	require.NoError(t, service.Delete(ctx, festivalID, drinks.ID))
+	mockRepo.AssertExpectations(t)
+}
@@ -68,7 +68,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 func (h *Handler) StartConversation(c *gin.Context) {
 	var req StartConversationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -117,7 +117,7 @@ func (h *Handler) SendMessage(c *gin.Context) {
 
 	var req SendMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -223,7 +223,7 @@ func (h *Handler) RateConversation(c *gin.Context) {
 
 	var req RateConversationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -296,7 +296,7 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 
 	var req ChatbotConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -327,7 +327,7 @@ func (h *Handler) CreateFAQEntry(c *gin.Context) {
 
 	var req FAQEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -428,7 +428,7 @@ func (h *Handler) UpdateFAQEntry(c *gin.Context) {
 
 	var req FAQEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
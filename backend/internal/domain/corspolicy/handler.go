@@ -0,0 +1,146 @@
+package corspolicy
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival CORS origin routes, which should be restricted
+// to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	origins := r.Group("/cors/origins")
+	{
+		origins.GET("", h.ListOrigins)
+		origins.POST("", h.AddOrigin)
+		origins.DELETE("/:originId", h.RemoveOrigin)
+	}
+}
+
+// ListOrigins lists the origins allowed on a festival
+// @Summary List festival CORS origins
+// @Description Get the browser origins allowed to call the routes of the festival on top of the platform origins, and the custom domain of the festival branding, whose https origin is allowed without being listed
+// @Tags cors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=OriginsResponse} "Origins"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/cors/origins [get]
+func (h *Handler) ListOrigins(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	origins, err := h.service.ListOrigins(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, origins)
+}
+
+// AddOrigin allows an origin on a festival
+// @Summary Add festival CORS origin
+// @Description Allow a browser origin, such as a festival web app on its own domain, to call the routes of the festival. Origins are scheme://host[:port] without path or wildcard, and https on a public host in production. The origin applies on every instance without a restart.
+// @Tags cors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateOriginRequest true "Origin"
+// @Success 201 {object} response.Response{data=Origin} "Origin allowed"
+// @Failure 400 {object} response.ErrorResponse "Invalid origin"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 409 {object} response.ErrorResponse "Origin already allowed"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/cors/origins [post]
+func (h *Handler) AddOrigin(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateOriginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	origin, err := h.service.AddOrigin(c.Request.Context(), festivalID, userID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, origin)
+}
+
+// RemoveOrigin stops allowing an origin on a festival
+// @Summary Remove festival CORS origin
+// @Tags cors
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param originId path string true "Origin ID" format(uuid)
+// @Success 204 "Removed"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Origin not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/cors/origins/{originId} [delete]
+func (h *Handler) RemoveOrigin(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	id, err := uuid.Parse(c.Param("originId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid origin ID", nil)
+		return
+	}
+
+	if err := h.service.RemoveOrigin(c.Request.Context(), festivalID, id, userID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOriginNotFound):
+		response.NotFound(c, "Origin not found")
+	case errors.Is(err, ErrOriginExists):
+		response.Conflict(c, "ORIGIN_EXISTS", err.Error())
+	case errors.Is(err, ErrInvalidOrigin), errors.Is(err, ErrInsecureOrigin):
+		response.BadRequest(c, "INVALID_ORIGIN", err.Error(), nil)
+	case errors.Is(err, ErrTooManyOrigins):
+		response.BadRequest(c, "TOO_MANY_ORIGINS", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,53 @@
+package corspolicy
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReloadChannel is the Redis pub/sub channel origin changes are broadcast on, so that
+// every API instance drops the origins it cached for the festival
+const ReloadChannel = "corspolicy:reload"
+
+// MaxOriginsPerFestival caps the origins an organizer can allow on a festival
+const MaxOriginsPerFestival = 20
+
+// CORS policy errors
+var (
+	ErrOriginNotFound = errors.New("origin not found")
+	ErrOriginExists   = errors.New("origin already allowed")
+	ErrTooManyOrigins = errors.New("too many origins, at most 20 per festival")
+	ErrInvalidOrigin  = errors.New("origin must be scheme://host[:port], without path or wildcard")
+	ErrInsecureOrigin = errors.New("origin must use https on a public host in production")
+)
+
+// Origin is a browser origin allowed on the routes of a festival, e.g. a festival web
+// app hosted on its own domain
+type Origin struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null"`
+	Origin      string     `json:"origin" gorm:"not null"`
+	Description string     `json:"description,omitempty"`
+	CreatedBy   *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func (Origin) TableName() string {
+	return "festival_cors_origins"
+}
+
+// CreateOriginRequest is the request to allow an origin on a festival
+type CreateOriginRequest struct {
+	Origin      string `json:"origin" binding:"required,max=255"` // https://app.myfestival.com
+	Description string `json:"description" binding:"max=255"`
+}
+
+// OriginsResponse lists the origins allowed on a festival
+type OriginsResponse struct {
+	Origins []Origin `json:"origins"`
+	// CustomDomain is the domain of the festival branding, whose https origin is
+	// allowed without being listed
+	CustomDomain *string `json:"customDomain,omitempty"`
+}
@@ -0,0 +1,99 @@
+package corspolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateOrigin(ctx context.Context, origin *Origin) error
+	GetOrigin(ctx context.Context, id uuid.UUID) (*Origin, error)
+	ListOrigins(ctx context.Context, festivalID uuid.UUID) ([]Origin, error)
+	DeleteOrigin(ctx context.Context, id uuid.UUID) error
+	// GetFestivalIDBySlug returns the ID of the festival with a slug, or nil
+	GetFestivalIDBySlug(ctx context.Context, slug string) (*uuid.UUID, error)
+	// GetCustomDomain returns the custom domain of the festival branding, or nil
+	GetCustomDomain(ctx context.Context, festivalID uuid.UUID) (*string, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateOrigin(ctx context.Context, origin *Origin) error {
+	if err := r.db.WithContext(ctx).Create(origin).Error; err != nil {
+		return fmt.Errorf("failed to create CORS origin: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetOrigin(ctx context.Context, id uuid.UUID) (*Origin, error) {
+	var origin Origin
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&origin).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get CORS origin: %w", err)
+	}
+	return &origin, nil
+}
+
+func (r *repository) ListOrigins(ctx context.Context, festivalID uuid.UUID) ([]Origin, error) {
+	var origins []Origin
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ?", festivalID).
+		Order("created_at ASC").
+		Find(&origins).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CORS origins: %w", err)
+	}
+	return origins, nil
+}
+
+func (r *repository) DeleteOrigin(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&Origin{}).Error; err != nil {
+		return fmt.Errorf("failed to delete CORS origin: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetFestivalIDBySlug(ctx context.Context, slug string) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Table("festivals").
+		Where("slug = ?", slug).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival by slug: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+func (r *repository) GetCustomDomain(ctx context.Context, festivalID uuid.UUID) (*string, error) {
+	var domains []string
+	err := r.db.WithContext(ctx).
+		Table("festival_branding").
+		Where("festival_id = ? AND custom_domain IS NOT NULL AND custom_domain <> ''", festivalID).
+		Limit(1).
+		Pluck("custom_domain", &domains).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival custom domain: %w", err)
+	}
+	if len(domains) == 0 {
+		return nil, nil
+	}
+	return &domains[0], nil
+}
@@ -0,0 +1,59 @@
+package corspolicy
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateOrigin(ctx context.Context, origin *Origin) error {
+	args := m.Called(ctx, origin)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetOrigin(ctx context.Context, id uuid.UUID) (*Origin, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Origin), args.Error(1)
+}
+
+func (m *MockRepository) ListOrigins(ctx context.Context, festivalID uuid.UUID) ([]Origin, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Origin), args.Error(1)
+}
+
+func (m *MockRepository) DeleteOrigin(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFestivalIDBySlug(ctx context.Context, slug string) (*uuid.UUID, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetCustomDomain(ctx context.Context, festivalID uuid.UUID) (*string, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*string), args.Error(1)
+}
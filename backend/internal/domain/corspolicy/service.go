@@ -0,0 +1,421 @@
+package corspolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// maxCachedEntries bounds the festivals and rejections kept in memory, which requests
+// with made-up festival IDs or origins could otherwise grow without limit
+const maxCachedEntries = 10000
+
+// Auditor records the rejected origins; satisfied by security.SecurityAuditor
+type Auditor interface {
+	LogEvent(ctx context.Context, event *security.SecurityEvent)
+}
+
+// AuditLogger records origin changes; satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Config configures the festival CORS policy
+type Config struct {
+	// Production only accepts https origins on public hosts
+	Production bool
+	// CacheTTL is how long the origins of a festival are kept in memory. Changes are
+	// applied at once on every instance when a broadcaster is set; the TTL bounds how
+	// stale an instance missing a broadcast can be.
+	CacheTTL time.Duration
+	// RejectionAuditInterval is how often the same origin rejected on the same festival
+	// is recorded, so that a misconfigured app does not flood the security events
+	RejectionAuditInterval time.Duration
+}
+
+// DefaultConfig returns the default festival CORS policy configuration
+func DefaultConfig() Config {
+	return Config{
+		Production:             true,
+		CacheTTL:               5 * time.Minute,
+		RejectionAuditInterval: 10 * time.Minute,
+	}
+}
+
+// Service decides which browser origins may call the routes of a festival, on top of
+// the platform origins allowed everywhere: the origins organizers list for the festival
+// web apps hosted on their own domains, and the custom domain of the festival branding.
+// The origins of a festival are loaded on its first cross-origin request and kept in
+// memory, so preflights do not hit the database.
+type Service struct {
+	repo    Repository
+	config  Config
+	auditor Auditor
+	audit   AuditLogger
+	redis   *redis.Client
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	policies map[string]*festivalPolicy // By festival ID or slug, as found in the path
+	rejected map[string]time.Time       // Last audit per festival and origin
+}
+
+// festivalPolicy is the set of origins allowed on a festival
+type festivalPolicy struct {
+	festivalID uuid.UUID // Nil when the festival does not exist
+	origins    map[string]bool
+	loadedAt   time.Time
+}
+
+// NewService creates a festival CORS policy service
+func NewService(repo Repository, config Config) *Service {
+	return &Service{
+		repo:     repo,
+		config:   config,
+		now:      time.Now,
+		policies: make(map[string]*festivalPolicy),
+		rejected: make(map[string]time.Time),
+	}
+}
+
+// SetAuditor records the rejected origins as security events
+func (s *Service) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
+}
+
+// SetAuditLogger records origin changes in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// SetBroadcaster tells the other instances to drop the origins of a festival through
+// Redis when they change
+func (s *Service) SetBroadcaster(client *redis.Client) {
+	s.redis = client
+}
+
+// Listen drops the cached origins of a festival whenever another instance changes them,
+// until ctx is done
+func (s *Service) Listen(ctx context.Context) error {
+	if s.redis == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	pubsub := s.redis.Subscribe(ctx, ReloadChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to CORS policy channel: %w", err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				if festivalID, err := uuid.Parse(msg.Payload); err == nil {
+					s.invalidate(festivalID)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// AllowOrigin tells whether a browser origin not allowed platform-wide may call a route
+// of a festival, given by its ID or slug, or by nothing for the routes outside the
+// festivals. Rejected origins are recorded as security events. Origins are rejected
+// when the festival origins cannot be loaded.
+func (s *Service) AllowOrigin(ctx context.Context, festival, origin, ip, method, path string) bool {
+	origin = strings.ToLower(origin)
+
+	var festivalID uuid.UUID
+	if festival != "" {
+		policy, err := s.policy(ctx, festival)
+		if err != nil {
+			log.Error().Err(err).Str("festival", festival).Msg("Failed to load festival CORS origins")
+		} else {
+			if policy.origins[origin] {
+				return true
+			}
+			festivalID = policy.festivalID
+		}
+	}
+
+	s.rejectedOrigin(ctx, festival, festivalID, origin, ip, method, path)
+	return false
+}
+
+// ListOrigins lists the origins allowed on a festival
+func (s *Service) ListOrigins(ctx context.Context, festivalID uuid.UUID) (*OriginsResponse, error) {
+	origins, err := s.repo.ListOrigins(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	customDomain, err := s.repo.GetCustomDomain(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if origins == nil {
+		origins = []Origin{}
+	}
+	return &OriginsResponse{Origins: origins, CustomDomain: customDomain}, nil
+}
+
+// AddOrigin allows an origin on a festival and applies it on every instance
+func (s *Service) AddOrigin(ctx context.Context, festivalID uuid.UUID, userID *uuid.UUID, req CreateOriginRequest) (*Origin, error) {
+	normalized, err := NormalizeOrigin(req.Origin, s.config.Production)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListOrigins(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range existing {
+		if o.Origin == normalized {
+			return nil, ErrOriginExists
+		}
+	}
+	if len(existing) >= MaxOriginsPerFestival {
+		return nil, ErrTooManyOrigins
+	}
+
+	origin := &Origin{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		Origin:      normalized,
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   userID,
+		CreatedAt:   s.now(),
+	}
+	if err := s.repo.CreateOrigin(ctx, origin); err != nil {
+		return nil, err
+	}
+
+	s.changed(ctx, userID, "create", origin)
+	return origin, nil
+}
+
+// RemoveOrigin stops allowing an origin on a festival, on every instance
+func (s *Service) RemoveOrigin(ctx context.Context, festivalID, id uuid.UUID, userID *uuid.UUID) error {
+	origin, err := s.repo.GetOrigin(ctx, id)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.FestivalID != festivalID {
+		return ErrOriginNotFound
+	}
+
+	if err := s.repo.DeleteOrigin(ctx, id); err != nil {
+		return err
+	}
+
+	s.changed(ctx, userID, "delete", origin)
+	return nil
+}
+
+// policy returns the origins allowed on a festival, from memory while they are fresh
+func (s *Service) policy(ctx context.Context, festival string) (*festivalPolicy, error) {
+	now := s.now()
+	s.mu.RLock()
+	policy, ok := s.policies[festival]
+	s.mu.RUnlock()
+	if ok && now.Sub(policy.loadedAt) < s.config.CacheTTL {
+		return policy, nil
+	}
+
+	policy, err := s.load(ctx, festival)
+	if err != nil {
+		return nil, err
+	}
+	policy.loadedAt = now
+
+	s.mu.Lock()
+	if len(s.policies) >= maxCachedEntries {
+		s.policies = make(map[string]*festivalPolicy)
+	}
+	s.policies[festival] = policy
+	s.mu.Unlock()
+	return policy, nil
+}
+
+// load reads the origins allowed on a festival given by its ID or slug. Origins that
+// are not valid anymore, such as http origins added before the production defaults,
+// are skipped.
+func (s *Service) load(ctx context.Context, festival string) (*festivalPolicy, error) {
+	policy := &festivalPolicy{origins: make(map[string]bool)}
+
+	festivalID, err := uuid.Parse(festival)
+	if err != nil {
+		id, err := s.repo.GetFestivalIDBySlug(ctx, festival)
+		if err != nil {
+			return nil, err
+		}
+		if id == nil {
+			return policy, nil
+		}
+		festivalID = *id
+	}
+	policy.festivalID = festivalID
+
+	origins, err := s.repo.ListOrigins(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range origins {
+		normalized, err := NormalizeOrigin(o.Origin, s.config.Production)
+		if err != nil {
+			log.Warn().Err(err).Str("origin_id", o.ID.String()).Msg("Skipping invalid festival CORS origin")
+			continue
+		}
+		policy.origins[normalized] = true
+	}
+
+	customDomain, err := s.repo.GetCustomDomain(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if customDomain != nil {
+		if normalized, err := NormalizeOrigin("https://"+*customDomain, s.config.Production); err == nil {
+			policy.origins[normalized] = true
+		}
+	}
+	return policy, nil
+}
+
+// invalidate drops the cached origins of a festival, under its ID and its slug
+func (s *Service) invalidate(festivalID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, policy := range s.policies {
+		if policy.festivalID == festivalID {
+			delete(s.policies, key)
+		}
+	}
+}
+
+// rejectedOrigin records a rejected origin, at most once per RejectionAuditInterval for
+// the same festival and origin
+func (s *Service) rejectedOrigin(ctx context.Context, festival string, festivalID uuid.UUID, origin, ip, method, path string) {
+	log.Debug().Str("origin", origin).Str("festival", festival).Str("path", path).Msg("CORS origin rejected")
+	if s.auditor == nil {
+		return
+	}
+
+	now := s.now()
+	key := festival + " " + origin
+	s.mu.Lock()
+	last, seen := s.rejected[key]
+	if seen && now.Sub(last) < s.config.RejectionAuditInterval {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.rejected) >= maxCachedEntries {
+		s.rejected = make(map[string]time.Time)
+	}
+	s.rejected[key] = now
+	s.mu.Unlock()
+
+	event := &security.SecurityEvent{
+		Type:      security.EventAuthzCORSRejected,
+		Severity:  security.SeverityWarning,
+		IPAddress: ip,
+		Resource:  path,
+		Action:    method,
+		Result:    "rejected",
+		Details: map[string]interface{}{
+			"origin": origin,
+		},
+	}
+	if festival != "" {
+		event.Details["festival"] = festival
+	}
+	if festivalID != uuid.Nil {
+		event.FestivalID = festivalID.String()
+	}
+	s.auditor.LogEvent(ctx, event)
+}
+
+// changed drops the origins of the festival here and on the other instances, and
+// audits the change
+func (s *Service) changed(ctx context.Context, userID *uuid.UUID, op string, origin *Origin) {
+	s.invalidate(origin.FestivalID)
+
+	if s.redis != nil {
+		if err := s.redis.Publish(ctx, ReloadChannel, origin.FestivalID.String()).Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to broadcast festival CORS origin change")
+		}
+	}
+
+	if s.audit != nil {
+		festivalID := origin.FestivalID
+		s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     userID,
+			Action:     audit.ActionSettingsUpdate,
+			Resource:   "cors_origin",
+			ResourceID: origin.ID.String(),
+			FestivalID: &festivalID,
+			Metadata: map[string]interface{}{
+				"op":     op,
+				"origin": origin.Origin,
+			},
+		})
+	}
+}
+
+// NormalizeOrigin validates an origin and returns it as browsers send it: lowercase,
+// without the default port. Paths, wildcards and credentials are refused; in
+// production only https origins on public host names are.
+func NormalizeOrigin(raw string, production bool) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.Contains(raw, "*") {
+		return "", ErrInvalidOrigin
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Opaque != "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" ||
+		(u.Path != "" && u.Path != "/") {
+		return "", ErrInvalidOrigin
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (scheme != "http" && scheme != "https") || host == "" {
+		return "", ErrInvalidOrigin
+	}
+
+	if production {
+		if scheme != "https" || host == "localhost" || strings.HasSuffix(host, ".localhost") || net.ParseIP(host) != nil {
+			return "", ErrInsecureOrigin
+		}
+	}
+
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return scheme + "://" + host, nil
+}
@@ -0,0 +1,169 @@
+package corspolicy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditor struct {
+	events []*security.SecurityEvent
+}
+
+func (a *fakeAuditor) LogEvent(ctx context.Context, event *security.SecurityEvent) {
+	a.events = append(a.events, event)
+}
+
+func TestNormalizeOrigin(t *testing.T) {
+	valid := map[string]string{
+		"https://App.MyFestival.com":      "https://app.myfestival.com",
+		"https://app.myfestival.com/":     "https://app.myfestival.com",
+		"https://app.myfestival.com:443":  "https://app.myfestival.com",
+		"https://app.myfestival.com:8443": "https://app.myfestival.com:8443",
+		" https://myfestival.com ":        "https://myfestival.com",
+	}
+	for raw, want := range valid {
+		got, err := NormalizeOrigin(raw, true)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+
+	for _, raw := range []string{
+		"", "*", "https://*.myfestival.com", "myfestival.com", "null", "ftp://myfestival.com",
+		"https://myfestival.com/app", "https://myfestival.com?a=1", "https://user@myfestival.com",
+	} {
+		_, err := NormalizeOrigin(raw, false)
+		assert.ErrorIs(t, err, ErrInvalidOrigin, raw)
+	}
+
+	// Production only takes https origins on public host names
+	for _, raw := range []string{"http://myfestival.com", "https://localhost:3000", "https://10.0.0.12", "https://app.localhost"} {
+		_, err := NormalizeOrigin(raw, true)
+		assert.ErrorIs(t, err, ErrInsecureOrigin, raw)
+	}
+	got, err := NormalizeOrigin("http://localhost:3000", false)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:3000", got)
+	got, err = NormalizeOrigin("http://[::1]:80", false)
+	require.NoError(t, err)
+	assert.Equal(t, "http://[::1]", got)
+}
+
+func TestService_AllowOrigin(t *testing.T) {
+	mockRepo := NewMockRepository()
+	festivalID, otherID := uuid.New(), uuid.New()
+	customDomain := "Tickets.SummerBeats.be"
+	mockRepo.On("GetFestivalIDBySlug", mock.Anything, "summer-beats").Return(&festivalID, nil).Once()
+	mockRepo.On("GetFestivalIDBySlug", mock.Anything, "unknown-festival").Return(nil, nil).Once()
+	mockRepo.On("ListOrigins", mock.Anything, festivalID).Return([]Origin{
+		{ID: uuid.New(), FestivalID: festivalID, Origin: "https://app.summerbeats.be"},
+		{ID: uuid.New(), FestivalID: festivalID, Origin: "http://staging.summerbeats.be"}, // Skipped in production
+	}, nil)
+	mockRepo.On("ListOrigins", mock.Anything, otherID).Return([]Origin{
+		{ID: uuid.New(), FestivalID: otherID, Origin: "https://app.other.be"},
+	}, nil)
+	mockRepo.On("GetCustomDomain", mock.Anything, festivalID).Return(&customDomain, nil)
+	mockRepo.On("GetCustomDomain", mock.Anything, otherID).Return(nil, nil)
+	auditor := &fakeAuditor{}
+	service := NewService(mockRepo, DefaultConfig())
+	service.SetAuditor(auditor)
+	ctx := context.Background()
+
+	allow := func(festival, origin string) bool {
+		return service.AllowOrigin(ctx, festival, origin, "203.0.113.7", "OPTIONS", "/api/v1/festivals/"+festival+"/stands")
+	}
+	assert.True(t, allow(festivalID.String(), "https://app.summerbeats.be"))
+	assert.True(t, allow(festivalID.String(), "https://APP.summerbeats.be"))
+	assert.True(t, allow("summer-beats", "https://app.summerbeats.be"), "festivals are also found by slug")
+	assert.True(t, allow(festivalID.String(), "https://tickets.summerbeats.be"), "the custom domain of the branding")
+	assert.False(t, allow(festivalID.String(), "http://staging.summerbeats.be"))
+	assert.False(t, allow(festivalID.String(), "https://app.other.be"), "origins are scoped to their festival")
+	assert.False(t, allow(otherID.String(), "https://app.summerbeats.be"))
+	assert.False(t, allow("unknown-festival", "https://app.summerbeats.be"))
+	assert.False(t, service.AllowOrigin(ctx, "", "https://app.summerbeats.be", "203.0.113.7", "GET", "/api/v1/me"))
+
+	// Origins are loaded once per festival ID or slug and TTL
+	mockRepo.AssertNumberOfCalls(t, "ListOrigins", 3)
+	mockRepo.AssertExpectations(t)
+
+	require.NotEmpty(t, auditor.events)
+	event := auditor.events[0]
+	assert.Equal(t, security.EventAuthzCORSRejected, event.Type)
+	assert.Equal(t, festivalID.String(), event.FestivalID)
+	assert.Equal(t, "http://staging.summerbeats.be", event.Details["origin"])
+	assert.Equal(t, "203.0.113.7", event.IPAddress)
+	assert.Equal(t, "OPTIONS", event.Action)
+}
+
+func TestService_RejectionAuditInterval(t *testing.T) {
+	mockRepo := NewMockRepository()
+	mockRepo.On("ListOrigins", mock.Anything, mock.Anything).Return([]Origin(nil), nil)
+	mockRepo.On("GetCustomDomain", mock.Anything, mock.Anything).Return(nil, nil)
+	auditor := &fakeAuditor{}
+	now := time.Date(2026, 7, 18, 21, 0, 0, 0, time.UTC)
+	service := NewService(mockRepo, DefaultConfig())
+	service.now = func() time.Time { return now }
+	service.SetAuditor(auditor)
+	ctx := context.Background()
+	festival := uuid.New().String()
+
+	for i := 0; i < 5; i++ {
+		assert.False(t, service.AllowOrigin(ctx, festival, "https://evil.example", "203.0.113.7", "GET", "/"))
+	}
+	assert.Len(t, auditor.events, 1, "a misconfigured app is recorded once per interval")
+
+	service.AllowOrigin(ctx, festival, "https://other.example", "203.0.113.7", "GET", "/")
+	assert.Len(t, auditor.events, 2)
+
+	now = now.Add(DefaultConfig().RejectionAuditInterval)
+	service.AllowOrigin(ctx, festival, "https://evil.example", "203.0.113.7", "GET", "/")
+	assert.Len(t, auditor.events, 3)
+}
+
+func TestService_AddRemoveOrigin(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, DefaultConfig())
+	festivalID := uuid.New()
+	festival := festivalID.String()
+	ctx := context.Background()
+	mockRepo.On("GetCustomDomain", mock.Anything, festivalID).Return(nil, nil)
+
+	mockRepo.On("ListOrigins", mock.Anything, festivalID).Return([]Origin(nil), nil).Twice()
+	assert.False(t, service.AllowOrigin(ctx, festival, "https://app.summerbeats.be", "", "GET", "/"))
+
+	mockRepo.On("CreateOrigin", mock.Anything, mock.AnythingOfType("*corspolicy.Origin")).Return(nil).Once()
+	origin, err := service.AddOrigin(ctx, festivalID, nil, CreateOriginRequest{Origin: "https://App.SummerBeats.be/"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.summerbeats.be", origin.Origin)
+
+	mockRepo.On("ListOrigins", mock.Anything, festivalID).Return([]Origin{*origin}, nil).Twice()
+	assert.True(t, service.AllowOrigin(ctx, festival, "https://app.summerbeats.be", "", "GET", "/"), "the cached origins are dropped on change")
+
+	_, err = service.AddOrigin(ctx, festivalID, nil, CreateOriginRequest{Origin: "https://app.summerbeats.be"})
+	assert.ErrorIs(t, err, ErrOriginExists)
+	_, err = service.AddOrigin(ctx, festivalID, nil, CreateOriginRequest{Origin: "http://app.summerbeats.be"})
+	assert.ErrorIs(t, err, ErrInsecureOrigin)
+	mockRepo.AssertNumberOfCalls(t, "CreateOrigin", 1)
+
+	mockRepo.On("GetOrigin", mock.Anything, origin.ID).Return(origin, nil)
+	assert.ErrorIs(t, service.RemoveOrigin(ctx, uuid.New(), origin.ID, nil), ErrOriginNotFound, "origins of another festival")
+	mockRepo.On("DeleteOrigin", mock.Anything, origin.ID).Return(nil).Once()
+	require.NoError(t, service.RemoveOrigin(ctx, festivalID, origin.ID, nil))
+	mockRepo.On("ListOrigins", mock.Anything, festivalID).Return([]Origin(nil), nil).Once()
+	assert.False(t, service.AllowOrigin(ctx, festival, "https://app.summerbeats.be", "", "GET", "/"))
+
+	full := make([]Origin, MaxOriginsPerFestival)
+	for i := range full {
+		full[i] = Origin{ID: uuid.New(), FestivalID: festivalID, Origin: "https://app" + uuid.NewString()[:8] + ".summerbeats.be"}
+	}
+	mockRepo.On("ListOrigins", mock.Anything, festivalID).Return(full, nil).Once()
+	_, err = service.AddOrigin(ctx, festivalID, nil, CreateOriginRequest{Origin: "https://one-more.summerbeats.be"})
+	assert.ErrorIs(t, err, ErrTooManyOrigins)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,422 @@
+package dayclose
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped day close and shift handover routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	closes := r.Group("/day-closes")
+	{
+		closes.GET("", h.ListCloses)
+		closes.POST("", h.CloseDay)
+		closes.GET("/preview", h.Preview)
+		closes.GET("/:closeId", h.GetClose)
+		closes.GET("/:closeId/z-report", h.GetZReport)
+		closes.GET("/:closeId/adjustments", h.ListAdjustments)
+	}
+
+	handovers := r.Group("/shift-handovers")
+	{
+		handovers.GET("", h.ListHandovers)
+		handovers.POST("", h.CreateHandover)
+	}
+}
+
+// RegisterAdjustmentRoutes registers the adjustment flow of closed days, which should
+// be restricted to organizers
+func (h *Handler) RegisterAdjustmentRoutes(r *gin.RouterGroup) {
+	r.POST("/day-closes/:closeId/adjustments", h.CreateAdjustment)
+}
+
+// ListCloses lists the day closes of the festival
+// @Summary List day closes
+// @Description List the end-of-day closes of the festival, latest business day first
+// @Tags day-closes
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the closes of this stand" format(uuid)
+// @Param from query string false "First business day (2006-01-02)"
+// @Param to query string false "Last business day (2006-01-02)"
+// @Success 200 {object} response.Response{data=[]DayClose} "Day closes"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes [get]
+func (h *Handler) ListCloses(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var filter CloseFilter
+	if filter.StandID, ok = standQuery(c); !ok {
+		return
+	}
+	if filter.From, ok = dateQuery(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = dateQuery(c, "to"); !ok {
+		return
+	}
+
+	closes, err := h.service.ListCloses(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, closes)
+}
+
+// Preview runs the reconciliation of a business day without closing it
+// @Summary Preview day close
+// @Description Reconcile the cash, card and wallet takings of a business day without closing it. Business days start at 06:00 festival time.
+// @Tags day-closes
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param date query string true "Business day (2006-01-02)"
+// @Param standId query string false "Stand ID, the whole festival when omitted" format(uuid)
+// @Success 200 {object} response.Response{data=ZReport} "Draft Z-report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes/preview [get]
+func (h *Handler) Preview(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := standQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Preview(c.Request.Context(), festivalID, standID, c.Query("date"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// CloseDay closes a business day
+// @Summary Close business day
+// @Description Freeze the orders of a business day at a stand, or at every stand when standId is omitted, reconcile the takings and issue the numbered Z-report. Closed days can only be changed through adjustments.
+// @Tags day-closes
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CloseDayRequest true "Day close"
+// @Success 201 {object} response.Response{data=DayClose} "Day closed"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or day not started"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Failure 409 {object} response.ErrorResponse "Day already closed"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes [post]
+func (h *Handler) CloseDay(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CloseDayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	dc, err := h.service.CloseDay(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, dc)
+}
+
+// GetClose returns a day close with its adjustments
+// @Summary Get day close
+// @Description Get a day close with its frozen Z-report, its adjustments and the net sales after adjustments
+// @Tags day-closes
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param closeId path string true "Day close ID" format(uuid)
+// @Success 200 {object} response.Response{data=CloseDetail} "Day close"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Day close not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes/{closeId} [get]
+func (h *Handler) GetClose(c *gin.Context) {
+	festivalID, closeID, ok := closeParams(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.service.GetClose(c.Request.Context(), festivalID, closeID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, detail)
+}
+
+// GetZReport returns the Z-report document of a day close
+// @Summary Get Z-report document
+// @Description Render the Z-report of a day close as a plain text document, with the adjustments made after closing
+// @Tags day-closes
+// @Produce plain
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param closeId path string true "Day close ID" format(uuid)
+// @Success 200 {string} string "Z-report"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Day close not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes/{closeId}/z-report [get]
+func (h *Handler) GetZReport(c *gin.Context) {
+	festivalID, closeID, ok := closeParams(c)
+	if !ok {
+		return
+	}
+
+	detail, document, err := h.service.RenderZReport(c.Request.Context(), festivalID, closeID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+detail.ReportNumber+`.txt"`)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(document))
+}
+
+// ListAdjustments lists the adjustments of a day close
+// @Summary List day close adjustments
+// @Description List the audited changes made to a closed business day
+// @Tags day-closes
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param closeId path string true "Day close ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Adjustment} "Adjustments"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Day close not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes/{closeId}/adjustments [get]
+func (h *Handler) ListAdjustments(c *gin.Context) {
+	festivalID, closeID, ok := closeParams(c)
+	if !ok {
+		return
+	}
+
+	adjustments, err := h.service.ListAdjustments(c.Request.Context(), festivalID, closeID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, adjustments)
+}
+
+// CreateAdjustment changes a closed business day
+// @Summary Adjust closed day
+// @Description Refund or cancel an order of a closed business day, or record a manual correction of its takings. Adjustments are audited and cannot be edited or removed.
+// @Tags day-closes
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param closeId path string true "Day close ID" format(uuid)
+// @Param request body CreateAdjustmentRequest true "Adjustment"
+// @Success 201 {object} response.Response{data=Adjustment} "Adjustment recorded"
+// @Failure 400 {object} response.ErrorResponse "Invalid adjustment"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Day close or order not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/day-closes/{closeId}/adjustments [post]
+func (h *Handler) CreateAdjustment(c *gin.Context) {
+	festivalID, closeID, ok := closeParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	adjustment, err := h.service.CreateAdjustment(c.Request.Context(), festivalID, closeID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, adjustment)
+}
+
+// ListHandovers lists the shift handovers of a business day
+// @Summary List shift handovers
+// @Description List the shift handovers of a business day, of every stand unless standId is given
+// @Tags day-closes
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param date query string true "Business day (2006-01-02)"
+// @Param standId query string false "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]ShiftHandover} "Shift handovers"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/shift-handovers [get]
+func (h *Handler) ListHandovers(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := standQuery(c)
+	if !ok {
+		return
+	}
+
+	handovers, err := h.service.ListHandovers(c.Request.Context(), festivalID, standID, c.Query("date"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, handovers)
+}
+
+// CreateHandover records a shift handover at a stand
+// @Summary Hand over shift
+// @Description Record the cash handed over at the end of a shift, against the cash the stand took since its previous handover of the business day
+// @Tags day-closes
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateHandoverRequest true "Shift handover"
+// @Success 201 {object} response.Response{data=ShiftHandover} "Shift handed over"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Failure 409 {object} response.ErrorResponse "Day already closed"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/shift-handovers [post]
+func (h *Handler) CreateHandover(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateHandoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	handover, err := h.service.CreateHandover(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, handover)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func closeParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	closeID, err := uuid.Parse(c.Param("closeId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid day close ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, closeID, true
+}
+
+// standQuery parses the optional standId query parameter
+func standQuery(c *gin.Context) (*uuid.UUID, bool) {
+	raw := c.Query("standId")
+	if raw == "" {
+		return nil, true
+	}
+	standID, err := uuid.Parse(raw)
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+		return nil, false
+	}
+	return &standID, true
+}
+
+// dateQuery parses an optional 2006-01-02 query parameter
+func dateQuery(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	date, err := time.Parse(DateLayout, raw)
+	if err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid "+name+" date, expected YYYY-MM-DD", nil)
+		return nil, false
+	}
+	return &date, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	case errors.Is(err, ErrCloseNotFound):
+		response.NotFound(c, "Day close not found")
+	case errors.Is(err, ErrOrderNotFound):
+		response.NotFound(c, "Order not found")
+	case errors.Is(err, ErrDayAlreadyClosed):
+		response.Conflict(c, "DAY_CLOSED", err.Error())
+	case errors.Is(err, ErrInvalidDate):
+		response.BadRequest(c, "INVALID_DATE", err.Error(), nil)
+	case errors.Is(err, ErrDayNotStarted):
+		response.BadRequest(c, "DAY_NOT_STARTED", err.Error(), nil)
+	case errors.Is(err, ErrOrderNotInClose), errors.Is(err, ErrInvalidAdjustment),
+		errors.Is(err, ErrOrderRequired), errors.Is(err, ErrManualAmount):
+		response.BadRequest(c, "INVALID_ADJUSTMENT", err.Error(), nil)
+	case errors.Is(err, ErrAdjustmentFailed):
+		response.BadRequest(c, "ADJUSTMENT_FAILED", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,262 @@
+package dayclose
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+)
+
+// Day close errors
+var (
+	ErrFestivalNotFound  = errors.New("festival not found")
+	ErrStandNotFound     = errors.New("stand not found")
+	ErrCloseNotFound     = errors.New("day close not found")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidDate       = errors.New("business date must be formatted as YYYY-MM-DD")
+	ErrDayNotStarted     = errors.New("business day has not started yet")
+	ErrDayAlreadyClosed  = errors.New("business day is already closed")
+	ErrOrderNotInClose   = errors.New("order does not belong to the closed business day")
+	ErrInvalidAdjustment = errors.New("adjustment type must be ORDER_REFUND, ORDER_CANCEL or MANUAL")
+	ErrOrderRequired     = errors.New("order adjustments need an order ID")
+	ErrManualAmount      = errors.New("manual adjustments need a payment method and a non-zero amount")
+	ErrAdjustmentFailed  = errors.New("order could not be adjusted")
+)
+
+// DateLayout is the format of business dates
+const DateLayout = "2006-01-02"
+
+// Payment methods reconciled by a day close, as recorded on orders
+const (
+	PaymentMethodWallet = "wallet"
+	PaymentMethodCash   = "cash"
+	PaymentMethodCard   = "card"
+)
+
+// PaymentMethods lists the reconciled payment methods in report order
+var PaymentMethods = []string{PaymentMethodCash, PaymentMethodCard, PaymentMethodWallet}
+
+// BusinessDayBounds returns the start (inclusive) and end (exclusive) of the business
+// day date, the operational day of the festival calendar; sales made after midnight and
+// before the day start belong to the previous day
+func BusinessDayBounds(date time.Time, cal tz.Calendar) (time.Time, time.Time) {
+	return cal.Bounds(date)
+}
+
+// BusinessDate returns the business day t belongs to in cal, as a UTC midnight
+func BusinessDate(t time.Time, cal tz.Calendar) time.Time {
+	date := cal.DateOf(t)
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseBusinessDate parses a YYYY-MM-DD business date
+func ParseBusinessDate(s string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return date, nil
+}
+
+// DayClose freezes the orders of a business day at a stand, or at every stand of the
+// festival when StandID is nil, with the reconciliation at the time of closing
+type DayClose struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID     uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID        *uuid.UUID `json:"standId,omitempty" gorm:"type:uuid"` // Nil for a festival-wide close
+	BusinessDate   time.Time  `json:"businessDate" gorm:"type:date;not null"`
+	PeriodStart    time.Time  `json:"periodStart" gorm:"not null"`
+	PeriodEnd      time.Time  `json:"periodEnd" gorm:"not null"`
+	ReportNumber   string     `json:"reportNumber" gorm:"not null"`
+	Report         ZReport    `json:"report" gorm:"type:jsonb;not null"`
+	NetSales       int64      `json:"netSales" gorm:"not null"`       // In cents
+	CashVariance   *int64     `json:"cashVariance,omitempty"`         // Counted minus expected cash, when counted
+	CardVariance   *int64     `json:"cardVariance,omitempty"`         // Terminal minus expected card total, when entered
+	WalletVariance int64      `json:"walletVariance" gorm:"not null"` // Wallet ledger minus wallet orders
+	Notes          string     `json:"notes,omitempty"`
+	ClosedBy       *uuid.UUID `json:"closedBy,omitempty" gorm:"type:uuid"`
+	ClosedAt       time.Time  `json:"closedAt"`
+}
+
+func (DayClose) TableName() string {
+	return "day_closes"
+}
+
+// ZReport is the end-of-day report of a close. It is frozen when the day is closed:
+// later corrections are listed as adjustments next to it, never merged into it.
+type ZReport struct {
+	Number          string          `json:"number,omitempty"` // Empty on previews
+	FestivalName    string          `json:"festivalName"`
+	StandName       string          `json:"standName,omitempty"` // Empty for a festival-wide close
+	Timezone        string          `json:"timezone"`
+	BusinessDate    string          `json:"businessDate"`
+	PeriodStart     time.Time       `json:"periodStart"`
+	PeriodEnd       time.Time       `json:"periodEnd"`
+	Orders          int64           `json:"orders"` // Paid and refunded orders
+	GrossSales      int64           `json:"grossSales"`
+	Refunds         int64           `json:"refunds"`
+	NetSales        int64           `json:"netSales"`
+	VoidedOrders    int64           `json:"voidedOrders"`
+	VoidedAmount    int64           `json:"voidedAmount"`
+	CancelledOrders int64           `json:"cancelledOrders"`
+	OpenOrders      int64           `json:"openOrders"` // Pending orders left unpaid, frozen with the day
+	CashIn          int64           `json:"cashIn"`     // Wallet top-ups paid in cash at the stands
+	Payments        []PaymentTotals `json:"payments"`
+	Handovers       []ShiftHandover `json:"handovers"`
+	GeneratedAt     time.Time       `json:"generatedAt"`
+}
+
+// Value implements driver.Valuer for the jsonb column
+func (r ZReport) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner for the jsonb column
+func (r *ZReport) Scan(value interface{}) error {
+	if value == nil {
+		*r = ZReport{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to scan ZReport: expected []byte, got %T", value)
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// PaymentTotals reconciles the sales of one payment method. Expected is what the
+// cash drawer, card terminal or wallet ledger should show; Counted is what it showed.
+type PaymentTotals struct {
+	Method   string `json:"method"`
+	Orders   int64  `json:"orders"`
+	Sales    int64  `json:"sales"`
+	Refunds  int64  `json:"refunds"`
+	Net      int64  `json:"net"`
+	Expected int64  `json:"expected"`
+	Counted  *int64 `json:"counted,omitempty"`
+	Variance *int64 `json:"variance,omitempty"`
+}
+
+// OrderTotals is a group of the orders of a business day by status and payment method
+type OrderTotals struct {
+	Status        string `json:"status"`
+	PaymentMethod string `json:"paymentMethod"`
+	Orders        int64  `json:"orders"`
+	Amount        int64  `json:"amount"`
+}
+
+// Scope is the festival, and the stand if any, a close covers
+type Scope struct {
+	FestivalID   uuid.UUID
+	FestivalName string
+	Timezone     string
+	DayStartsAt  string // HH:MM
+	StandID      *uuid.UUID
+	StandName    string
+}
+
+// Calendar returns the operational days of the festival of the scope
+func (s *Scope) Calendar() tz.Calendar {
+	return tz.NewCalendar(s.Timezone, s.DayStartsAt)
+}
+
+// ShiftHandover records the cash handed over when a shift ends at a stand, against
+// what the stand took in cash since the previous handover of the business day
+type ShiftHandover struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID   uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID      uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	BusinessDate time.Time  `json:"businessDate" gorm:"type:date;not null"`
+	FromStaffID  *uuid.UUID `json:"fromStaffId,omitempty" gorm:"type:uuid"` // Outgoing staff member
+	ToStaffID    *uuid.UUID `json:"toStaffId,omitempty" gorm:"type:uuid"`   // Incoming staff member, nil at the end of the day
+	PeriodStart  time.Time  `json:"periodStart" gorm:"not null"`
+	HandedOverAt time.Time  `json:"handedOverAt" gorm:"not null"`
+	Orders       int64      `json:"orders" gorm:"not null"`
+	ExpectedCash int64      `json:"expectedCash" gorm:"not null"`
+	CountedCash  int64      `json:"countedCash" gorm:"not null"`
+	CashVariance int64      `json:"cashVariance" gorm:"not null"`
+	Notes        string     `json:"notes,omitempty"`
+}
+
+func (ShiftHandover) TableName() string {
+	return "shift_handovers"
+}
+
+// AdjustmentType is the kind of change made to a closed business day
+type AdjustmentType string
+
+const (
+	AdjustmentOrderRefund AdjustmentType = "ORDER_REFUND" // Refund of a paid order of the day
+	AdjustmentOrderCancel AdjustmentType = "ORDER_CANCEL" // Cancellation of an order left open
+	AdjustmentManual      AdjustmentType = "MANUAL"       // Correction of a counting or entry error
+)
+
+// IsValid checks if the adjustment type is valid
+func (t AdjustmentType) IsValid() bool {
+	return t == AdjustmentOrderRefund || t == AdjustmentOrderCancel || t == AdjustmentManual
+}
+
+// Adjustment is an audited change to a closed business day. Adjustments are the only
+// way to change a closed day and cannot be edited or removed.
+type Adjustment struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CloseID       uuid.UUID      `json:"closeId" gorm:"type:uuid;not null;index"`
+	FestivalID    uuid.UUID      `json:"festivalId" gorm:"type:uuid;not null"`
+	Type          AdjustmentType `json:"type" gorm:"not null"`
+	OrderID       *uuid.UUID     `json:"orderId,omitempty" gorm:"type:uuid"`
+	PaymentMethod string         `json:"paymentMethod,omitempty"`
+	Amount        int64          `json:"amount" gorm:"not null"` // Change to the day's net sales in cents
+	Reason        string         `json:"reason" gorm:"not null"`
+	CreatedBy     *uuid.UUID     `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt     time.Time      `json:"createdAt"`
+}
+
+func (Adjustment) TableName() string {
+	return "day_close_adjustments"
+}
+
+// CloseDetail is a day close with its adjustments and the net sales they lead to
+type CloseDetail struct {
+	DayClose
+	Adjustments      []Adjustment `json:"adjustments"`
+	AdjustedNetSales int64        `json:"adjustedNetSales"`
+}
+
+// CloseFilter narrows the listed day closes
+type CloseFilter struct {
+	StandID *uuid.UUID
+	From    *time.Time
+	To      *time.Time
+}
+
+// CloseDayRequest closes a business day. Without a stand the whole festival is closed.
+type CloseDayRequest struct {
+	StandID           *uuid.UUID `json:"standId,omitempty"`
+	BusinessDate      string     `json:"businessDate" binding:"required"` // YYYY-MM-DD
+	CountedCash       *int64     `json:"countedCash,omitempty" binding:"omitempty,min=0"`
+	CardTerminalTotal *int64     `json:"cardTerminalTotal,omitempty" binding:"omitempty,min=0"`
+	Notes             string     `json:"notes,omitempty" binding:"max=1000"`
+}
+
+// CreateHandoverRequest records a shift handover at a stand
+type CreateHandoverRequest struct {
+	StandID     uuid.UUID  `json:"standId" binding:"required"`
+	ToStaffID   *uuid.UUID `json:"toStaffId,omitempty"`
+	CountedCash int64      `json:"countedCash" binding:"min=0"`
+	Notes       string     `json:"notes,omitempty" binding:"max=1000"`
+}
+
+// CreateAdjustmentRequest changes a closed business day
+type CreateAdjustmentRequest struct {
+	Type          AdjustmentType `json:"type" binding:"required"`
+	OrderID       *uuid.UUID     `json:"orderId,omitempty"`
+	PaymentMethod string         `json:"paymentMethod,omitempty" binding:"omitempty,oneof=wallet cash card"`
+	Amount        int64          `json:"amount,omitempty"` // Manual adjustments only, in cents
+	Reason        string         `json:"reason" binding:"required,min=3,max=500"`
+}
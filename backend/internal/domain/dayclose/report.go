@@ -0,0 +1,201 @@
+package dayclose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+)
+
+// Order statuses summed by the reconciliation
+const (
+	statusPending   = "PENDING"
+	statusPaid      = "PAID"
+	statusCancelled = "CANCELLED"
+	statusRefunded  = "REFUNDED"
+	statusVoided    = "VOIDED"
+)
+
+// Counts are the figures a business day's orders are reconciled against
+type Counts struct {
+	CashIn       int64  // Cash wallet top-ups taken at the stands
+	CountedCash  *int64 // Cash counted in the drawers, nil when not counted
+	CardTerminal *int64 // Total printed by the card terminals, nil when not entered
+	WalletLedger int64  // Wallet purchases still standing in the ledger
+}
+
+// BuildZReport sums the orders of a business day by payment method and reconciles each
+// method: cash against the counted drawers, card against the terminal total and wallet
+// orders against the wallet ledger. Refunded orders count as sales refunded the same day.
+func BuildZReport(totals []OrderTotals, counts Counts) *ZReport {
+	report := &ZReport{Payments: make([]PaymentTotals, 0, len(PaymentMethods))}
+
+	for _, t := range totals {
+		switch t.Status {
+		case statusVoided:
+			report.VoidedOrders += t.Orders
+			report.VoidedAmount += t.Amount
+		case statusCancelled:
+			report.CancelledOrders += t.Orders
+		case statusPending:
+			report.OpenOrders += t.Orders
+		}
+	}
+
+	for _, method := range PaymentMethods {
+		p := paymentTotals(totals, method)
+		switch method {
+		case PaymentMethodCash:
+			p.Expected = p.Net + counts.CashIn
+			p.Counted = counts.CountedCash
+		case PaymentMethodCard:
+			p.Expected = p.Net
+			p.Counted = counts.CardTerminal
+		case PaymentMethodWallet:
+			ledger := counts.WalletLedger
+			p.Expected = p.Net
+			p.Counted = &ledger
+		}
+		if p.Counted != nil {
+			variance := *p.Counted - p.Expected
+			p.Variance = &variance
+		}
+
+		report.Orders += p.Orders
+		report.GrossSales += p.Sales
+		report.Refunds += p.Refunds
+		report.Payments = append(report.Payments, p)
+	}
+
+	report.NetSales = report.GrossSales - report.Refunds
+	report.CashIn = counts.CashIn
+	return report
+}
+
+// BuildCloseDetail adds the adjustments of a close to it
+func BuildCloseDetail(dc *DayClose, adjustments []Adjustment) *CloseDetail {
+	if adjustments == nil {
+		adjustments = []Adjustment{}
+	}
+
+	detail := &CloseDetail{
+		DayClose:         *dc,
+		Adjustments:      adjustments,
+		AdjustedNetSales: dc.NetSales,
+	}
+	for _, a := range adjustments {
+		detail.AdjustedNetSales += a.Amount
+	}
+	return detail
+}
+
+// paymentTotals sums the paid and refunded orders of a payment method
+func paymentTotals(totals []OrderTotals, method string) PaymentTotals {
+	p := PaymentTotals{Method: method}
+	for _, t := range totals {
+		if t.PaymentMethod != method {
+			continue
+		}
+		switch t.Status {
+		case statusPaid:
+			p.Orders += t.Orders
+			p.Sales += t.Amount
+		case statusRefunded:
+			p.Orders += t.Orders
+			p.Sales += t.Amount
+			p.Refunds += t.Amount
+		}
+	}
+	p.Net = p.Sales - p.Refunds
+	return p
+}
+
+// countOrders counts the paid and refunded orders
+func countOrders(totals []OrderTotals) int64 {
+	var orders int64
+	for _, t := range totals {
+		if t.Status == statusPaid || t.Status == statusRefunded {
+			orders += t.Orders
+		}
+	}
+	return orders
+}
+
+// RenderZReport renders the Z-report of a close as a plain text document, followed by
+// the adjustments made after the day was closed
+func RenderZReport(detail *CloseDetail) string {
+	r := detail.Report
+	loc := tz.Load(r.Timezone)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Z-REPORT %s\n", r.Number)
+	fmt.Fprintf(&b, "%s\n", r.FestivalName)
+	if r.StandName != "" {
+		fmt.Fprintf(&b, "Stand: %s\n", r.StandName)
+	} else {
+		b.WriteString("Stand: all stands\n")
+	}
+	fmt.Fprintf(&b, "Business day: %s (%s)\n", r.BusinessDate, r.Timezone)
+	fmt.Fprintf(&b, "Period: %s - %s\n", r.PeriodStart.In(loc).Format("2006-01-02 15:04"), r.PeriodEnd.In(loc).Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "Closed: %s\n", detail.ClosedAt.In(loc).Format("2006-01-02 15:04"))
+
+	b.WriteString("\nSALES\n")
+	writeLine(&b, "Orders", fmt.Sprintf("%d", r.Orders))
+	writeLine(&b, "Gross sales", formatCents(r.GrossSales))
+	writeLine(&b, "Refunds", formatCents(-r.Refunds))
+	writeLine(&b, "Net sales", formatCents(r.NetSales))
+	writeLine(&b, "Voided orders", fmt.Sprintf("%d (%s)", r.VoidedOrders, formatCents(r.VoidedAmount)))
+	writeLine(&b, "Cancelled orders", fmt.Sprintf("%d", r.CancelledOrders))
+	writeLine(&b, "Open orders", fmt.Sprintf("%d", r.OpenOrders))
+	writeLine(&b, "Cash top-ups", formatCents(r.CashIn))
+
+	b.WriteString("\nRECONCILIATION\n")
+	fmt.Fprintf(&b, "%-8s %6s %12s %12s %12s %12s\n", "Method", "Orders", "Net", "Expected", "Counted", "Variance")
+	for _, p := range r.Payments {
+		counted, variance := "-", "-"
+		if p.Counted != nil {
+			counted = formatCents(*p.Counted)
+		}
+		if p.Variance != nil {
+			variance = formatCents(*p.Variance)
+		}
+		fmt.Fprintf(&b, "%-8s %6d %12s %12s %12s %12s\n", p.Method, p.Orders, formatCents(p.Net), formatCents(p.Expected), counted, variance)
+	}
+
+	if len(r.Handovers) > 0 {
+		b.WriteString("\nSHIFT HANDOVERS\n")
+		for _, h := range r.Handovers {
+			fmt.Fprintf(&b, "%s  expected %s  counted %s  variance %s\n",
+				h.HandedOverAt.In(loc).Format("15:04"), formatCents(h.ExpectedCash), formatCents(h.CountedCash), formatCents(h.CashVariance))
+		}
+	}
+
+	if detail.Notes != "" {
+		fmt.Fprintf(&b, "\nNotes: %s\n", detail.Notes)
+	}
+
+	if len(detail.Adjustments) > 0 {
+		b.WriteString("\nADJUSTMENTS AFTER CLOSE\n")
+		for _, a := range detail.Adjustments {
+			fmt.Fprintf(&b, "%s  %-12s %-6s %12s  %s\n",
+				a.CreatedAt.In(loc).Format("2006-01-02 15:04"), a.Type, a.PaymentMethod, formatCents(a.Amount), a.Reason)
+		}
+		writeLine(&b, "Adjusted net sales", formatCents(detail.AdjustedNetSales))
+	}
+
+	return b.String()
+}
+
+func writeLine(b *strings.Builder, label, value string) {
+	fmt.Fprintf(b, "%-20s %20s\n", label, value)
+}
+
+// formatCents formats an amount in cents with two decimals
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,273 @@
+package dayclose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NumberFunc allocates the Z-report number of a close within the transaction that
+// stores it
+type NumberFunc func(tx *gorm.DB) (string, error)
+
+// SealFunc attests a numbered close within the transaction that stores it
+type SealFunc func(tx *gorm.DB) error
+
+type Repository interface {
+	GetScope(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) (*Scope, error)
+	GetOrderTotals(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) ([]OrderTotals, error)
+	GetCashIn(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) (int64, error)
+	GetWalletLedger(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) (int64, error)
+
+	CreateClose(ctx context.Context, dc *DayClose, number NumberFunc, seal SealFunc) error
+	GetClose(ctx context.Context, festivalID, id uuid.UUID) (*DayClose, error)
+	FindClose(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, date time.Time) (*DayClose, error)
+	ListCloses(ctx context.Context, festivalID uuid.UUID, filter CloseFilter) ([]DayClose, error)
+	IsClosed(ctx context.Context, festivalID, standID uuid.UUID, at time.Time) (bool, error)
+
+	CreateHandover(ctx context.Context, handover *ShiftHandover) error
+	GetLastHandover(ctx context.Context, standID uuid.UUID, date time.Time) (*ShiftHandover, error)
+	ListHandovers(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, date time.Time) ([]ShiftHandover, error)
+
+	CreateAdjustment(ctx context.Context, adjustment *Adjustment) error
+	ListAdjustments(ctx context.Context, closeID uuid.UUID) ([]Adjustment, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// GetScope returns the festival and stand a close covers, or nil when the festival
+// does not exist or the stand is not one of its stands
+func (r *repository) GetScope(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) (*Scope, error) {
+	var scopes []Scope
+	var err error
+	if standID == nil {
+		err = r.db.WithContext(ctx).Raw(`
+			SELECT f.id AS festival_id, f.name AS festival_name, f.timezone, f.day_starts_at
+			FROM public.festivals f
+			WHERE f.id = ?`,
+			festivalID,
+		).Scan(&scopes).Error
+	} else {
+		err = r.db.WithContext(ctx).Raw(`
+			SELECT f.id AS festival_id, f.name AS festival_name, f.timezone, f.day_starts_at,
+				s.id AS stand_id, s.name AS stand_name
+			FROM public.stands s
+			INNER JOIN public.festivals f ON f.id = s.festival_id
+			WHERE f.id = ? AND s.id = ?`,
+			festivalID, *standID,
+		).Scan(&scopes).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get close scope: %w", err)
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	return &scopes[0], nil
+}
+
+// GetOrderTotals groups the orders created from from (inclusive) to to (exclusive) by
+// status and payment method
+func (r *repository) GetOrderTotals(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) ([]OrderTotals, error) {
+	var totals []OrderTotals
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT o.status, o.payment_method, COUNT(*) AS orders, COALESCE(SUM(o.total_amount), 0) AS amount
+		FROM public.orders o
+		WHERE o.festival_id = ? AND (CAST(? AS uuid) IS NULL OR o.stand_id = ?)
+			AND o.created_at >= ? AND o.created_at < ?
+		GROUP BY 1, 2`,
+		festivalID, standID, standID, from, to,
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order totals: %w", err)
+	}
+	return totals, nil
+}
+
+// GetCashIn sums the wallet top-ups paid in cash at the stands
+func (r *repository) GetCashIn(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(SUM(t.amount), 0)
+		FROM public.transactions t
+		INNER JOIN public.wallets w ON w.id = t.wallet_id
+		WHERE w.festival_id = ? AND t.type = 'CASH_IN' AND t.status = 'COMPLETED'
+			AND t.stand_id IS NOT NULL AND (CAST(? AS uuid) IS NULL OR t.stand_id = ?)
+			AND t.created_at >= ? AND t.created_at < ?`,
+		festivalID, standID, standID, from, to,
+	).Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cash top-ups: %w", err)
+	}
+	return total, nil
+}
+
+// GetWalletLedger sums the wallet purchases at the stands that were not refunded, the
+// ledger side of the wallet orders
+func (r *repository) GetWalletLedger(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(-SUM(t.amount), 0)
+		FROM public.transactions t
+		INNER JOIN public.wallets w ON w.id = t.wallet_id
+		WHERE w.festival_id = ? AND t.type = 'PURCHASE' AND t.status = 'COMPLETED'
+			AND t.stand_id IS NOT NULL AND (CAST(? AS uuid) IS NULL OR t.stand_id = ?)
+			AND t.created_at >= ? AND t.created_at < ?`,
+		festivalID, standID, standID, from, to,
+	).Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallet ledger: %w", err)
+	}
+	return total, nil
+}
+
+func (r *repository) CreateClose(ctx context.Context, dc *DayClose, number NumberFunc, seal SealFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reportNumber, err := number(tx)
+		if err != nil {
+			return err
+		}
+		dc.ReportNumber = reportNumber
+		dc.Report.Number = reportNumber
+
+		if err := tx.Create(dc).Error; err != nil {
+			return fmt.Errorf("failed to create day close: %w", err)
+		}
+		if seal != nil {
+			return seal(tx)
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetClose(ctx context.Context, festivalID, id uuid.UUID) (*DayClose, error) {
+	var dc DayClose
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&dc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get day close: %w", err)
+	}
+	return &dc, nil
+}
+
+// FindClose returns the close covering a business day: the festival-wide close, or
+// the close of standID when given
+func (r *repository) FindClose(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, date time.Time) (*DayClose, error) {
+	query := r.db.WithContext(ctx).
+		Where("festival_id = ? AND business_date = ?", festivalID, date.Format(DateLayout))
+	if standID == nil {
+		query = query.Where("stand_id IS NULL")
+	} else {
+		query = query.Where("stand_id IS NULL OR stand_id = ?", *standID)
+	}
+
+	var dc DayClose
+	if err := query.Order("stand_id NULLS FIRST").First(&dc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find day close: %w", err)
+	}
+	return &dc, nil
+}
+
+func (r *repository) ListCloses(ctx context.Context, festivalID uuid.UUID, filter CloseFilter) ([]DayClose, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if filter.StandID != nil {
+		query = query.Where("stand_id = ?", *filter.StandID)
+	}
+	if filter.From != nil {
+		query = query.Where("business_date >= ?", filter.From.Format(DateLayout))
+	}
+	if filter.To != nil {
+		query = query.Where("business_date <= ?", filter.To.Format(DateLayout))
+	}
+
+	var closes []DayClose
+	if err := query.Order("business_date DESC, stand_id NULLS FIRST").Find(&closes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list day closes: %w", err)
+	}
+	return closes, nil
+}
+
+// IsClosed checks whether the business day of a stand at at was closed, by the stand
+// or festival-wide
+func (r *repository) IsClosed(ctx context.Context, festivalID, standID uuid.UUID, at time.Time) (bool, error) {
+	var closed bool
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM public.day_closes
+			WHERE festival_id = ? AND (stand_id IS NULL OR stand_id = ?)
+				AND period_start <= ? AND period_end > ?
+		)`,
+		festivalID, standID, at, at,
+	).Scan(&closed).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check day close: %w", err)
+	}
+	return closed, nil
+}
+
+func (r *repository) CreateHandover(ctx context.Context, handover *ShiftHandover) error {
+	if err := r.db.WithContext(ctx).Create(handover).Error; err != nil {
+		return fmt.Errorf("failed to create shift handover: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetLastHandover(ctx context.Context, standID uuid.UUID, date time.Time) (*ShiftHandover, error) {
+	var handover ShiftHandover
+	err := r.db.WithContext(ctx).
+		Where("stand_id = ? AND business_date = ?", standID, date.Format(DateLayout)).
+		Order("handed_over_at DESC").
+		First(&handover).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last shift handover: %w", err)
+	}
+	return &handover, nil
+}
+
+func (r *repository) ListHandovers(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, date time.Time) ([]ShiftHandover, error) {
+	query := r.db.WithContext(ctx).
+		Where("festival_id = ? AND business_date = ?", festivalID, date.Format(DateLayout))
+	if standID != nil {
+		query = query.Where("stand_id = ?", *standID)
+	}
+
+	var handovers []ShiftHandover
+	if err := query.Order("handed_over_at").Find(&handovers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list shift handovers: %w", err)
+	}
+	return handovers, nil
+}
+
+func (r *repository) CreateAdjustment(ctx context.Context, adjustment *Adjustment) error {
+	if err := r.db.WithContext(ctx).Create(adjustment).Error; err != nil {
+		return fmt.Errorf("failed to create day close adjustment: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListAdjustments(ctx context.Context, closeID uuid.UUID) ([]Adjustment, error) {
+	var adjustments []Adjustment
+	err := r.db.WithContext(ctx).Where("close_id = ?", closeID).Order("created_at").Find(&adjustments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list day close adjustments: %w", err)
+	}
+	return adjustments, nil
+}
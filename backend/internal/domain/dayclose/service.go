@@ -0,0 +1,480 @@
+package dayclose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/attestation"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/numbering"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// NumberAllocator numbers the Z-reports; satisfied by numbering.Service
+type NumberAllocator interface {
+	AllocateTx(ctx context.Context, tx *gorm.DB, festivalID uuid.UUID, docType numbering.DocumentType, allocatedBy *uuid.UUID, req numbering.AllocateRequest) (*numbering.AllocatedNumber, error)
+}
+
+// OrderAdjuster changes the orders of closed days; satisfied by order.Service
+type OrderAdjuster interface {
+	GetOrder(ctx context.Context, orderID uuid.UUID) (*order.Order, error)
+	RefundOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*order.Order, error)
+	CancelOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*order.Order, error)
+}
+
+// AuditLogger records the adjustments of closed days, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Attester seals the Z-reports of closed days in the attestation chain of the festival;
+// satisfied by attestation.Service
+type Attester interface {
+	AttestTx(ctx context.Context, tx *gorm.DB, req attestation.AttestRequest) (*attestation.Attestation, error)
+}
+
+// Service closes business days, reconciles their takings and keeps closed days frozen
+type Service struct {
+	repo    Repository
+	numbers NumberAllocator
+	orders  OrderAdjuster
+	audit   AuditLogger
+	attest  Attester
+	now     func() time.Time
+}
+
+// NewService creates a day close service
+func NewService(repo Repository, numbers NumberAllocator, orders OrderAdjuster) *Service {
+	return &Service{
+		repo:    repo,
+		numbers: numbers,
+		orders:  orders,
+		now:     time.Now,
+	}
+}
+
+// SetAuditLogger records the adjustments of closed days in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// SetAttester seals every Z-report in the attestation chain as its day is closed, so
+// that it can be proven unmodified later
+func (s *Service) SetAttester(attester Attester) {
+	s.attest = attester
+}
+
+// IsDayClosed reports whether the business day of a stand at at was closed; it backs
+// the order freeze
+func (s *Service) IsDayClosed(ctx context.Context, festivalID, standID uuid.UUID, at time.Time) (bool, error) {
+	return s.repo.IsClosed(ctx, festivalID, standID, at)
+}
+
+// Preview runs the reconciliation of a business day without closing it
+func (s *Service) Preview(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, businessDate string) (*ZReport, error) {
+	scope, date, err := s.scope(ctx, festivalID, standID, businessDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildReport(ctx, scope, date, nil, nil)
+}
+
+// CloseDay freezes the orders of a business day and stores its numbered Z-report.
+// A day can be closed once it has started; orders made later in the day are refused.
+func (s *Service) CloseDay(ctx context.Context, festivalID uuid.UUID, req CloseDayRequest, closedBy *uuid.UUID) (*DayClose, error) {
+	scope, date, err := s.scope(ctx, festivalID, req.StandID, req.BusinessDate)
+	if err != nil {
+		return nil, err
+	}
+
+	start, _ := BusinessDayBounds(date, scope.Calendar())
+	if s.now().Before(start) {
+		return nil, ErrDayNotStarted
+	}
+
+	existing, err := s.repo.FindClose(ctx, festivalID, req.StandID, date)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDayAlreadyClosed
+	}
+
+	report, err := s.buildReport(ctx, scope, date, req.CountedCash, req.CardTerminalTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	dc := &DayClose{
+		ID:           uuid.New(),
+		FestivalID:   festivalID,
+		StandID:      req.StandID,
+		BusinessDate: date,
+		PeriodStart:  report.PeriodStart,
+		PeriodEnd:    report.PeriodEnd,
+		Report:       *report,
+		NetSales:     report.NetSales,
+		Notes:        req.Notes,
+		ClosedBy:     closedBy,
+		ClosedAt:     report.GeneratedAt,
+	}
+	for _, p := range report.Payments {
+		switch p.Method {
+		case PaymentMethodCash:
+			dc.CashVariance = p.Variance
+		case PaymentMethodCard:
+			dc.CardVariance = p.Variance
+		case PaymentMethodWallet:
+			if p.Variance != nil {
+				dc.WalletVariance = *p.Variance
+			}
+		}
+	}
+
+	err = s.repo.CreateClose(ctx, dc, func(tx *gorm.DB) (string, error) {
+		number, err := s.numbers.AllocateTx(ctx, tx, festivalID, numbering.DocumentTypeZReport, closedBy, numbering.AllocateRequest{
+			ReferenceType: "day_close",
+			ReferenceID:   dc.ID.String(),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to number Z-report: %w", err)
+		}
+		return number.Number, nil
+	}, s.seal(ctx, dc))
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// DocumentContent returns the frozen Z-report of a close as it was attested, or nil
+// when there is no such close
+func (s *Service) DocumentContent(ctx context.Context, festivalID, id uuid.UUID) ([]byte, error) {
+	dc, err := s.repo.GetClose(ctx, festivalID, id)
+	if err != nil || dc == nil {
+		return nil, err
+	}
+	return json.Marshal(dc.Report)
+}
+
+// GetClose returns a day close with its adjustments
+func (s *Service) GetClose(ctx context.Context, festivalID, id uuid.UUID) (*CloseDetail, error) {
+	dc, err := s.getClose(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments, err := s.repo.ListAdjustments(ctx, dc.ID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildCloseDetail(dc, adjustments), nil
+}
+
+// ListCloses lists the day closes of a festival, latest first
+func (s *Service) ListCloses(ctx context.Context, festivalID uuid.UUID, filter CloseFilter) ([]DayClose, error) {
+	return s.repo.ListCloses(ctx, festivalID, filter)
+}
+
+// RenderZReport renders the Z-report document of a day close with its adjustments
+func (s *Service) RenderZReport(ctx context.Context, festivalID, id uuid.UUID) (*CloseDetail, string, error) {
+	detail, err := s.GetClose(ctx, festivalID, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return detail, RenderZReport(detail), nil
+}
+
+// CreateHandover records a shift handover at a stand. The expected cash covers what the
+// stand took in cash since its previous handover of the business day.
+func (s *Service) CreateHandover(ctx context.Context, festivalID uuid.UUID, req CreateHandoverRequest, fromStaffID *uuid.UUID) (*ShiftHandover, error) {
+	scope, err := s.repo.GetScope(ctx, festivalID, &req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if scope == nil {
+		return nil, ErrStandNotFound
+	}
+
+	now := s.now()
+	cal := scope.Calendar()
+	date := BusinessDate(now, cal)
+
+	closed, err := s.repo.IsClosed(ctx, festivalID, req.StandID, now)
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		return nil, ErrDayAlreadyClosed
+	}
+
+	since, _ := BusinessDayBounds(date, cal)
+	last, err := s.repo.GetLastHandover(ctx, req.StandID, date)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		since = last.HandedOverAt
+	}
+
+	totals, err := s.repo.GetOrderTotals(ctx, festivalID, &req.StandID, since, now)
+	if err != nil {
+		return nil, err
+	}
+	cashIn, err := s.repo.GetCashIn(ctx, festivalID, &req.StandID, since, now)
+	if err != nil {
+		return nil, err
+	}
+
+	cash := paymentTotals(totals, PaymentMethodCash)
+	expected := cash.Net + cashIn
+
+	handover := &ShiftHandover{
+		ID:           uuid.New(),
+		FestivalID:   festivalID,
+		StandID:      req.StandID,
+		BusinessDate: date,
+		FromStaffID:  fromStaffID,
+		ToStaffID:    req.ToStaffID,
+		PeriodStart:  since,
+		HandedOverAt: now,
+		Orders:       countOrders(totals),
+		ExpectedCash: expected,
+		CountedCash:  req.CountedCash,
+		CashVariance: req.CountedCash - expected,
+		Notes:        req.Notes,
+	}
+	if err := s.repo.CreateHandover(ctx, handover); err != nil {
+		return nil, err
+	}
+	return handover, nil
+}
+
+// ListHandovers lists the shift handovers of a business day, of every stand when
+// standID is nil
+func (s *Service) ListHandovers(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, businessDate string) ([]ShiftHandover, error) {
+	date, err := ParseBusinessDate(businessDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListHandovers(ctx, festivalID, standID, date)
+}
+
+// CreateAdjustment changes a closed business day through the audited adjustment flow.
+// Order adjustments refund or cancel an order of the day despite the freeze; manual
+// adjustments record a correction of the day's takings.
+func (s *Service) CreateAdjustment(ctx context.Context, festivalID, closeID uuid.UUID, req CreateAdjustmentRequest, createdBy *uuid.UUID) (*Adjustment, error) {
+	if !req.Type.IsValid() {
+		return nil, ErrInvalidAdjustment
+	}
+
+	dc, err := s.getClose(ctx, festivalID, closeID)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment := &Adjustment{
+		ID:         uuid.New(),
+		CloseID:    dc.ID,
+		FestivalID: festivalID,
+		Type:       req.Type,
+		Reason:     req.Reason,
+		CreatedBy:  createdBy,
+		CreatedAt:  s.now(),
+	}
+
+	switch req.Type {
+	case AdjustmentOrderRefund, AdjustmentOrderCancel:
+		if req.OrderID == nil {
+			return nil, ErrOrderRequired
+		}
+		o, err := s.closedDayOrder(ctx, dc, *req.OrderID)
+		if err != nil {
+			return nil, err
+		}
+
+		adjusted := order.WithClosedDayAdjustment(ctx)
+		if req.Type == AdjustmentOrderRefund {
+			if _, err := s.orders.RefundOrder(adjusted, o.ID, req.Reason, createdBy); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrAdjustmentFailed, err)
+			}
+			adjustment.Amount = -o.TotalAmount
+		} else {
+			if _, err := s.orders.CancelOrder(adjusted, o.ID, req.Reason, createdBy); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrAdjustmentFailed, err)
+			}
+		}
+		adjustment.OrderID = &o.ID
+		adjustment.PaymentMethod = o.PaymentMethod
+
+	case AdjustmentManual:
+		if req.PaymentMethod == "" || req.Amount == 0 {
+			return nil, ErrManualAmount
+		}
+		adjustment.PaymentMethod = req.PaymentMethod
+		adjustment.Amount = req.Amount
+	}
+
+	if err := s.repo.CreateAdjustment(ctx, adjustment); err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     createdBy,
+			Action:     audit.ActionUpdate,
+			Resource:   "day_close",
+			ResourceID: dc.ID.String(),
+			FestivalID: &festivalID,
+			Metadata: map[string]interface{}{
+				"reportNumber":  dc.ReportNumber,
+				"businessDate":  dc.Report.BusinessDate,
+				"adjustmentId":  adjustment.ID.String(),
+				"type":          adjustment.Type,
+				"orderId":       adjustment.OrderID,
+				"paymentMethod": adjustment.PaymentMethod,
+				"amount":        adjustment.Amount,
+				"reason":        adjustment.Reason,
+			},
+		})
+	}
+
+	return adjustment, nil
+}
+
+// ListAdjustments lists the adjustments of a day close
+func (s *Service) ListAdjustments(ctx context.Context, festivalID, closeID uuid.UUID) ([]Adjustment, error) {
+	dc, err := s.getClose(ctx, festivalID, closeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListAdjustments(ctx, dc.ID)
+}
+
+// buildReport runs the reconciliation of a business day
+func (s *Service) buildReport(ctx context.Context, scope *Scope, date time.Time, countedCash, cardTerminal *int64) (*ZReport, error) {
+	start, end := BusinessDayBounds(date, scope.Calendar())
+
+	// The day picks up where the close of the previous day ended, so that no sale is
+	// left out or counted twice when the festival moves its day start
+	previous, err := s.repo.FindClose(ctx, scope.FestivalID, scope.StandID, date.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.PeriodEnd.Before(end) {
+		start = previous.PeriodEnd
+	}
+
+	totals, err := s.repo.GetOrderTotals(ctx, scope.FestivalID, scope.StandID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	cashIn, err := s.repo.GetCashIn(ctx, scope.FestivalID, scope.StandID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	ledger, err := s.repo.GetWalletLedger(ctx, scope.FestivalID, scope.StandID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	handovers, err := s.repo.ListHandovers(ctx, scope.FestivalID, scope.StandID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	report := BuildZReport(totals, Counts{
+		CashIn:       cashIn,
+		CountedCash:  countedCash,
+		CardTerminal: cardTerminal,
+		WalletLedger: ledger,
+	})
+	report.FestivalName = scope.FestivalName
+	report.StandName = scope.StandName
+	report.Timezone = scope.Timezone
+	report.BusinessDate = date.Format(DateLayout)
+	report.PeriodStart = start
+	report.PeriodEnd = end
+	report.Handovers = handovers
+	report.GeneratedAt = s.now()
+	if report.Handovers == nil {
+		report.Handovers = []ShiftHandover{}
+	}
+	return report, nil
+}
+
+// seal attests the numbered Z-report of a close, when an attester is set
+func (s *Service) seal(ctx context.Context, dc *DayClose) SealFunc {
+	if s.attest == nil {
+		return nil
+	}
+	return func(tx *gorm.DB) error {
+		content, err := json.Marshal(dc.Report)
+		if err != nil {
+			return fmt.Errorf("failed to encode Z-report: %w", err)
+		}
+		_, err = s.attest.AttestTx(ctx, tx, attestation.AttestRequest{
+			FestivalID:     dc.FestivalID,
+			DocumentType:   attestation.DocumentDayClose,
+			DocumentID:     dc.ID,
+			DocumentNumber: dc.ReportNumber,
+			Content:        content,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to attest Z-report: %w", err)
+		}
+		return nil
+	}
+}
+
+// closedDayOrder returns an order of the business day of a close
+func (s *Service) closedDayOrder(ctx context.Context, dc *DayClose, orderID uuid.UUID) (*order.Order, error) {
+	o, err := s.orders.GetOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if o.FestivalID != dc.FestivalID || (dc.StandID != nil && o.StandID != *dc.StandID) ||
+		o.CreatedAt.Before(dc.PeriodStart) || !o.CreatedAt.Before(dc.PeriodEnd) {
+		return nil, ErrOrderNotInClose
+	}
+	return o, nil
+}
+
+func (s *Service) scope(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, businessDate string) (*Scope, time.Time, error) {
+	date, err := ParseBusinessDate(businessDate)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	scope, err := s.repo.GetScope(ctx, festivalID, standID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if scope == nil {
+		if standID != nil {
+			return nil, time.Time{}, ErrStandNotFound
+		}
+		return nil, time.Time{}, ErrFestivalNotFound
+	}
+	return scope, date, nil
+}
+
+func (s *Service) getClose(ctx context.Context, festivalID, id uuid.UUID) (*DayClose, error) {
+	dc, err := s.repo.GetClose(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if dc == nil {
+		return nil, ErrCloseNotFound
+	}
+	return dc, nil
+}
@@ -0,0 +1,497 @@
+package dayclose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/numbering"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakeRepository struct {
+	scope       Scope
+	orders      []order.Order
+	cashIn      int64
+	ledger      int64
+	closes      []DayClose
+	handovers   []ShiftHandover
+	adjustments []Adjustment
+}
+
+func (r *fakeRepository) GetScope(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) (*Scope, error) {
+	if festivalID != r.scope.FestivalID {
+		return nil, nil
+	}
+	scope := r.scope
+	if standID == nil {
+		scope.StandID, scope.StandName = nil, ""
+	} else if r.scope.StandID == nil || *standID != *r.scope.StandID {
+		return nil, nil
+	}
+	return &scope, nil
+}
+
+func (r *fakeRepository) GetOrderTotals(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) ([]OrderTotals, error) {
+	groups := make(map[string]*OrderTotals)
+	var totals []OrderTotals
+	for _, o := range r.orders {
+		if o.FestivalID != festivalID || (standID != nil && o.StandID != *standID) ||
+			o.CreatedAt.Before(from) || !o.CreatedAt.Before(to) {
+			continue
+		}
+		key := string(o.Status) + "/" + o.PaymentMethod
+		if groups[key] == nil {
+			groups[key] = &OrderTotals{Status: string(o.Status), PaymentMethod: o.PaymentMethod}
+		}
+		groups[key].Orders++
+		groups[key].Amount += o.TotalAmount
+	}
+	for _, g := range groups {
+		totals = append(totals, *g)
+	}
+	return totals, nil
+}
+
+func (r *fakeRepository) GetCashIn(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) (int64, error) {
+	return r.cashIn, nil
+}
+
+func (r *fakeRepository) GetWalletLedger(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time) (int64, error) {
+	return r.ledger, nil
+}
+
+func (r *fakeRepository) CreateClose(ctx context.Context, dc *DayClose, number NumberFunc, seal SealFunc) error {
+	reportNumber, err := number(nil)
+	if err != nil {
+		return err
+	}
+	dc.ReportNumber = reportNumber
+	dc.Report.Number = reportNumber
+	if seal != nil {
+		if err := seal(nil); err != nil {
+			return err
+		}
+	}
+	r.closes = append(r.closes, *dc)
+	return nil
+}
+
+func (r *fakeRepository) GetClose(ctx context.Context, festivalID, id uuid.UUID) (*DayClose, error) {
+	for _, dc := range r.closes {
+		if dc.ID == id && dc.FestivalID == festivalID {
+			found := dc
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) FindClose(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, date time.Time) (*DayClose, error) {
+	for _, dc := range r.closes {
+		if dc.FestivalID != festivalID || !dc.BusinessDate.Equal(date) {
+			continue
+		}
+		if dc.StandID == nil || (standID != nil && *dc.StandID == *standID) {
+			found := dc
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) ListCloses(ctx context.Context, festivalID uuid.UUID, filter CloseFilter) ([]DayClose, error) {
+	return r.closes, nil
+}
+
+func (r *fakeRepository) IsClosed(ctx context.Context, festivalID, standID uuid.UUID, at time.Time) (bool, error) {
+	for _, dc := range r.closes {
+		if dc.FestivalID == festivalID && (dc.StandID == nil || *dc.StandID == standID) &&
+			!at.Before(dc.PeriodStart) && at.Before(dc.PeriodEnd) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepository) CreateHandover(ctx context.Context, handover *ShiftHandover) error {
+	r.handovers = append(r.handovers, *handover)
+	return nil
+}
+
+func (r *fakeRepository) GetLastHandover(ctx context.Context, standID uuid.UUID, date time.Time) (*ShiftHandover, error) {
+	var last *ShiftHandover
+	for i := range r.handovers {
+		h := r.handovers[i]
+		if h.StandID == standID && h.BusinessDate.Equal(date) && (last == nil || h.HandedOverAt.After(last.HandedOverAt)) {
+			last = &h
+		}
+	}
+	return last, nil
+}
+
+func (r *fakeRepository) ListHandovers(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, date time.Time) ([]ShiftHandover, error) {
+	var handovers []ShiftHandover
+	for _, h := range r.handovers {
+		if h.FestivalID == festivalID && h.BusinessDate.Equal(date) && (standID == nil || h.StandID == *standID) {
+			handovers = append(handovers, h)
+		}
+	}
+	return handovers, nil
+}
+
+func (r *fakeRepository) CreateAdjustment(ctx context.Context, adjustment *Adjustment) error {
+	r.adjustments = append(r.adjustments, *adjustment)
+	return nil
+}
+
+func (r *fakeRepository) ListAdjustments(ctx context.Context, closeID uuid.UUID) ([]Adjustment, error) {
+	var adjustments []Adjustment
+	for _, a := range r.adjustments {
+		if a.CloseID == closeID {
+			adjustments = append(adjustments, a)
+		}
+	}
+	return adjustments, nil
+}
+
+type fakeNumbers struct {
+	last int64
+}
+
+func (n *fakeNumbers) AllocateTx(ctx context.Context, tx *gorm.DB, festivalID uuid.UUID, docType numbering.DocumentType, allocatedBy *uuid.UUID, req numbering.AllocateRequest) (*numbering.AllocatedNumber, error) {
+	n.last++
+	format := numbering.DefaultFormat(festivalID, docType)
+	return &numbering.AllocatedNumber{Number: format.Render(2025, n.last), ReferenceID: req.ReferenceID}, nil
+}
+
+type fakeOrders struct {
+	orders map[uuid.UUID]*order.Order
+}
+
+func (o *fakeOrders) GetOrder(ctx context.Context, orderID uuid.UUID) (*order.Order, error) {
+	if found, ok := o.orders[orderID]; ok {
+		return found, nil
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (o *fakeOrders) RefundOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*order.Order, error) {
+	found := o.orders[orderID]
+	if found.Status != order.OrderStatusPaid {
+		return nil, fmt.Errorf("only paid orders can be refunded")
+	}
+	found.Status = order.OrderStatusRefunded
+	return found, nil
+}
+
+func (o *fakeOrders) CancelOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*order.Order, error) {
+	found := o.orders[orderID]
+	found.Status = order.OrderStatusCancelled
+	return found, nil
+}
+
+type fakeAudit struct {
+	logged []audit.CreateAuditLogRequest
+}
+
+func (a *fakeAudit) LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest) {
+	a.logged = append(a.logged, req)
+}
+
+// fixture is a stand of a Brussels festival with orders on the business day of
+// 2025-07-12, which runs from 06:00 that day to 06:00 the next day
+type fixture struct {
+	service    *Service
+	repo       *fakeRepository
+	orders     *fakeOrders
+	audit      *fakeAudit
+	festivalID uuid.UUID
+	standID    uuid.UUID
+	loc        *time.Location
+}
+
+func newFixture(t *testing.T) *fixture {
+	loc, err := time.LoadLocation("Europe/Brussels")
+	require.NoError(t, err)
+
+	f := &fixture{
+		festivalID: uuid.New(),
+		standID:    uuid.New(),
+		loc:        loc,
+		orders:     &fakeOrders{orders: make(map[uuid.UUID]*order.Order)},
+		audit:      &fakeAudit{},
+	}
+	f.repo = &fakeRepository{
+		scope: Scope{
+			FestivalID:   f.festivalID,
+			FestivalName: "Summer Fest",
+			Timezone:     "Europe/Brussels",
+			StandID:      &f.standID,
+			StandName:    "Main Bar",
+		},
+	}
+	f.service = NewService(f.repo, &fakeNumbers{}, f.orders)
+	f.service.SetAuditLogger(f.audit)
+	f.service.now = func() time.Time { return time.Date(2025, 7, 13, 2, 30, 0, 0, loc) }
+	return f
+}
+
+func (f *fixture) addOrder(status order.OrderStatus, method string, amount int64, at time.Time) *order.Order {
+	o := &order.Order{
+		ID:            uuid.New(),
+		FestivalID:    f.festivalID,
+		StandID:       f.standID,
+		TotalAmount:   amount,
+		Status:        status,
+		PaymentMethod: method,
+		CreatedAt:     at,
+	}
+	f.repo.orders = append(f.repo.orders, *o)
+	f.orders.orders[o.ID] = o
+	return o
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// TestBusinessDate tests that sales after midnight belong to the previous business day
+func TestBusinessDate(t *testing.T) {
+	cal := tz.NewCalendar("Europe/Brussels", "")
+	loc := cal.Location
+
+	july12 := time.Date(2025, 7, 12, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, july12, BusinessDate(time.Date(2025, 7, 12, 6, 0, 0, 0, loc), cal))
+	assert.Equal(t, july12, BusinessDate(time.Date(2025, 7, 13, 5, 59, 0, 0, loc), cal))
+	assert.Equal(t, july12.AddDate(0, 0, 1), BusinessDate(time.Date(2025, 7, 13, 6, 0, 0, 0, loc), cal))
+
+	start, end := BusinessDayBounds(july12, cal)
+	assert.Equal(t, time.Date(2025, 7, 12, 6, 0, 0, 0, loc), start)
+	assert.Equal(t, time.Date(2025, 7, 13, 6, 0, 0, 0, loc), end)
+
+	// A festival whose days run from 10:00 to 10:00
+	cal = tz.NewCalendar("Europe/Brussels", "10:00")
+	assert.Equal(t, july12, BusinessDate(time.Date(2025, 7, 13, 9, 59, 0, 0, loc), cal))
+	start, _ = BusinessDayBounds(july12, cal)
+	assert.Equal(t, time.Date(2025, 7, 12, 10, 0, 0, 0, loc), start)
+}
+
+// TestBuildZReport tests the reconciliation of each payment method
+func TestBuildZReport(t *testing.T) {
+	totals := []OrderTotals{
+		{Status: "PAID", PaymentMethod: "cash", Orders: 3, Amount: 3000},
+		{Status: "REFUNDED", PaymentMethod: "cash", Orders: 1, Amount: 500},
+		{Status: "PAID", PaymentMethod: "card", Orders: 2, Amount: 4000},
+		{Status: "PAID", PaymentMethod: "wallet", Orders: 5, Amount: 2500},
+		{Status: "VOIDED", PaymentMethod: "cash", Orders: 1, Amount: 700},
+		{Status: "PENDING", PaymentMethod: "wallet", Orders: 2, Amount: 900},
+	}
+
+	report := BuildZReport(totals, Counts{
+		CashIn:       1000,
+		CountedCash:  int64Ptr(3900),
+		WalletLedger: 2400,
+	})
+
+	assert.Equal(t, int64(11), report.Orders)
+	assert.Equal(t, int64(10000), report.GrossSales)
+	assert.Equal(t, int64(500), report.Refunds)
+	assert.Equal(t, int64(9500), report.NetSales)
+	assert.Equal(t, int64(1), report.VoidedOrders)
+	assert.Equal(t, int64(700), report.VoidedAmount)
+	assert.Equal(t, int64(2), report.OpenOrders)
+	require.Len(t, report.Payments, 3)
+
+	cash, card, wallet := report.Payments[0], report.Payments[1], report.Payments[2]
+	assert.Equal(t, int64(3000), cash.Net)
+	assert.Equal(t, int64(4000), cash.Expected) // Net cash sales plus cash top-ups
+	require.NotNil(t, cash.Variance)
+	assert.Equal(t, int64(-100), *cash.Variance)
+
+	assert.Equal(t, int64(4000), card.Expected)
+	assert.Nil(t, card.Counted)
+	assert.Nil(t, card.Variance)
+
+	require.NotNil(t, wallet.Variance)
+	assert.Equal(t, int64(-100), *wallet.Variance)
+}
+
+// TestCloseDay tests closing a stand's business day
+func TestCloseDay(t *testing.T) {
+	f := newFixture(t)
+	f.addOrder(order.OrderStatusPaid, "cash", 1200, time.Date(2025, 7, 12, 18, 0, 0, 0, f.loc))
+	f.addOrder(order.OrderStatusPaid, "card", 800, time.Date(2025, 7, 13, 1, 0, 0, 0, f.loc))
+	f.addOrder(order.OrderStatusPaid, "cash", 999, time.Date(2025, 7, 12, 5, 0, 0, 0, f.loc)) // Previous business day
+	ctx := context.Background()
+
+	dc, err := f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{
+		StandID:      &f.standID,
+		BusinessDate: "2025-07-12",
+		CountedCash:  int64Ptr(1150),
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Z-2025-000001", dc.ReportNumber)
+	assert.Equal(t, "Z-2025-000001", dc.Report.Number)
+	assert.Equal(t, "Main Bar", dc.Report.StandName)
+	assert.Equal(t, int64(2000), dc.NetSales)
+	require.NotNil(t, dc.CashVariance)
+	assert.Equal(t, int64(-50), *dc.CashVariance)
+	assert.Nil(t, dc.CardVariance)
+
+	// The rest of the business day is frozen, the next one is not
+	closed, err := f.service.IsDayClosed(ctx, f.festivalID, f.standID, time.Date(2025, 7, 13, 4, 0, 0, 0, f.loc))
+	require.NoError(t, err)
+	assert.True(t, closed)
+	closed, err = f.service.IsDayClosed(ctx, f.festivalID, f.standID, time.Date(2025, 7, 13, 6, 0, 0, 0, f.loc))
+	require.NoError(t, err)
+	assert.False(t, closed)
+
+	_, err = f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-12"}, nil)
+	assert.ErrorIs(t, err, ErrDayAlreadyClosed)
+
+	_, err = f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-13"}, nil)
+	assert.ErrorIs(t, err, ErrDayNotStarted)
+
+	_, err = f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "12/07/2025"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidDate)
+}
+
+// TestCloseDay_DayStartMoved tests that the day after a change of day start picks up
+// where the previous close ended
+func TestCloseDay_DayStartMoved(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	_, err := f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-12"}, nil)
+	require.NoError(t, err)
+
+	// Days now run from 10:00; a sale at 08:00 on July 13 belongs to July 13
+	f.repo.scope.DayStartsAt = "10:00"
+	f.service.now = func() time.Time { return time.Date(2025, 7, 14, 2, 0, 0, 0, f.loc) }
+	f.addOrder(order.OrderStatusPaid, "card", 500, time.Date(2025, 7, 13, 8, 0, 0, 0, f.loc))
+	f.addOrder(order.OrderStatusPaid, "card", 700, time.Date(2025, 7, 13, 22, 0, 0, 0, f.loc))
+
+	dc, err := f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-13"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 13, 6, 0, 0, 0, f.loc), dc.PeriodStart.In(f.loc))
+	assert.Equal(t, time.Date(2025, 7, 14, 10, 0, 0, 0, f.loc), dc.PeriodEnd.In(f.loc))
+	assert.Equal(t, int64(1200), dc.NetSales)
+}
+
+// TestCloseDay_FestivalWide tests that a festival-wide close covers every stand
+func TestCloseDay_FestivalWide(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	dc, err := f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{BusinessDate: "2025-07-12"}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, dc.StandID)
+	assert.Empty(t, dc.Report.StandName)
+
+	_, err = f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-12"}, nil)
+	assert.ErrorIs(t, err, ErrDayAlreadyClosed)
+
+	closed, err := f.service.IsDayClosed(ctx, f.festivalID, uuid.New(), time.Date(2025, 7, 12, 20, 0, 0, 0, f.loc))
+	require.NoError(t, err)
+	assert.True(t, closed)
+}
+
+// TestCreateHandover tests that each handover covers the cash since the previous one
+func TestCreateHandover(t *testing.T) {
+	f := newFixture(t)
+	f.addOrder(order.OrderStatusPaid, "cash", 1000, time.Date(2025, 7, 12, 16, 0, 0, 0, f.loc))
+	f.addOrder(order.OrderStatusPaid, "card", 700, time.Date(2025, 7, 12, 17, 0, 0, 0, f.loc))
+	f.addOrder(order.OrderStatusPaid, "cash", 400, time.Date(2025, 7, 12, 23, 0, 0, 0, f.loc))
+	ctx := context.Background()
+
+	f.service.now = func() time.Time { return time.Date(2025, 7, 12, 20, 0, 0, 0, f.loc) }
+	first, err := f.service.CreateHandover(ctx, f.festivalID, CreateHandoverRequest{StandID: f.standID, CountedCash: 1000}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 12, 6, 0, 0, 0, f.loc), first.PeriodStart)
+	assert.Equal(t, int64(2), first.Orders)
+	assert.Equal(t, int64(1000), first.ExpectedCash)
+	assert.Equal(t, int64(0), first.CashVariance)
+
+	f.service.now = func() time.Time { return time.Date(2025, 7, 13, 1, 0, 0, 0, f.loc) }
+	second, err := f.service.CreateHandover(ctx, f.festivalID, CreateHandoverRequest{StandID: f.standID, CountedCash: 350}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.HandedOverAt, second.PeriodStart)
+	assert.Equal(t, int64(400), second.ExpectedCash)
+	assert.Equal(t, int64(-50), second.CashVariance)
+
+	// Handovers are listed on the Z-report, and no more can be made once closed
+	dc, err := f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-12"}, nil)
+	require.NoError(t, err)
+	assert.Len(t, dc.Report.Handovers, 2)
+
+	_, err = f.service.CreateHandover(ctx, f.festivalID, CreateHandoverRequest{StandID: f.standID}, nil)
+	assert.ErrorIs(t, err, ErrDayAlreadyClosed)
+}
+
+// TestCreateAdjustment tests the audited adjustment flow of closed days
+func TestCreateAdjustment(t *testing.T) {
+	f := newFixture(t)
+	paid := f.addOrder(order.OrderStatusPaid, "wallet", 1500, time.Date(2025, 7, 12, 21, 0, 0, 0, f.loc))
+	other := f.addOrder(order.OrderStatusPaid, "cash", 500, time.Date(2025, 7, 11, 21, 0, 0, 0, f.loc))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	dc, err := f.service.CloseDay(ctx, f.festivalID, CloseDayRequest{StandID: &f.standID, BusinessDate: "2025-07-12"}, nil)
+	require.NoError(t, err)
+
+	refund, err := f.service.CreateAdjustment(ctx, f.festivalID, dc.ID, CreateAdjustmentRequest{
+		Type:    AdjustmentOrderRefund,
+		OrderID: &paid.ID,
+		Reason:  "Customer charged twice",
+	}, &userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1500), refund.Amount)
+	assert.Equal(t, "wallet", refund.PaymentMethod)
+	assert.Equal(t, order.OrderStatusRefunded, paid.Status)
+
+	_, err = f.service.CreateAdjustment(ctx, f.festivalID, dc.ID, CreateAdjustmentRequest{
+		Type: AdjustmentManual, PaymentMethod: "cash", Amount: 200, Reason: "Miscounted drawer",
+	}, &userID)
+	require.NoError(t, err)
+
+	_, err = f.service.CreateAdjustment(ctx, f.festivalID, dc.ID, CreateAdjustmentRequest{
+		Type: AdjustmentOrderRefund, OrderID: &other.ID, Reason: "Wrong day",
+	}, &userID)
+	assert.ErrorIs(t, err, ErrOrderNotInClose)
+
+	_, err = f.service.CreateAdjustment(ctx, f.festivalID, dc.ID, CreateAdjustmentRequest{
+		Type: AdjustmentOrderRefund, OrderID: &paid.ID, Reason: "Refund again",
+	}, &userID)
+	assert.ErrorIs(t, err, ErrAdjustmentFailed)
+
+	_, err = f.service.CreateAdjustment(ctx, f.festivalID, dc.ID, CreateAdjustmentRequest{
+		Type: AdjustmentManual, PaymentMethod: "cash", Reason: "No amount",
+	}, &userID)
+	assert.ErrorIs(t, err, ErrManualAmount)
+
+	detail, err := f.service.GetClose(ctx, f.festivalID, dc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), detail.NetSales) // The Z-report stays frozen
+	assert.Equal(t, int64(200), detail.AdjustedNetSales)
+	assert.Len(t, detail.Adjustments, 2)
+
+	require.Len(t, f.audit.logged, 2)
+	assert.Equal(t, "day_close", f.audit.logged[0].Resource)
+	assert.Equal(t, &userID, f.audit.logged[0].UserID)
+
+	document := RenderZReport(detail)
+	assert.Contains(t, document, "Z-REPORT Z-2025-000001")
+	assert.Contains(t, document, "ADJUSTMENTS AFTER CLOSE")
+	assert.True(t, strings.Contains(document, "Customer charged twice"))
+}
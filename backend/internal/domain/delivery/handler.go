@@ -0,0 +1,454 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the management of delivery locations, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	locations := r.Group("/delivery-locations")
+	{
+		locations.GET("", h.ListLocations)
+		locations.POST("", h.CreateLocation)
+		locations.GET("/:locationId", h.GetLocation)
+		locations.PATCH("/:locationId", h.UpdateLocation)
+		locations.GET("/:locationId/qr", h.GetLocationQR)
+	}
+}
+
+// RegisterRunnerRoutes registers the delivery queue of the runners, which should be
+// restricted to staff
+func (h *Handler) RegisterRunnerRoutes(r *gin.RouterGroup) {
+	deliveries := r.Group("/deliveries")
+	{
+		deliveries.GET("", h.GetQueue)
+		deliveries.POST("/:orderId/assign", h.Assign)
+		deliveries.POST("/:orderId/delivered", h.ConfirmDelivered)
+	}
+}
+
+// RegisterAttendeeRoutes registers the lookup of scanned location QR codes, open to
+// every authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	r.GET("/delivery-locations/resolve", h.Resolve)
+}
+
+// ListLocations lists the tables and pitches of the festival
+// @Summary List delivery locations
+// @Description List the numbered tables and camping pitches orders can be delivered to
+// @Tags deliveries
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param kind query string false "Only this kind of location" Enums(TABLE, PITCH)
+// @Success 200 {object} response.Response{data=[]Location} "Delivery locations"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/delivery-locations [get]
+func (h *Handler) ListLocations(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var kind *LocationKind
+	if raw := c.Query("kind"); raw != "" {
+		k := LocationKind(raw)
+		if k != LocationKindTable && k != LocationKindPitch {
+			response.BadRequest(c, "INVALID_KIND", "Kind must be TABLE or PITCH", nil)
+			return
+		}
+		kind = &k
+	}
+
+	locations, err := h.service.ListLocations(c.Request.Context(), festivalID, kind)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, locations)
+}
+
+// CreateLocation adds a table or pitch
+// @Summary Create delivery location
+// @Description Add a numbered table or camping pitch runners deliver orders to. Codes are unique within the festival and case-insensitive.
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateLocationRequest true "Delivery location"
+// @Success 201 {object} response.Response{data=Location} "Created location"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Code already used"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/delivery-locations [post]
+func (h *Handler) CreateLocation(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	location, err := h.service.CreateLocation(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, location)
+}
+
+// GetLocation returns a table or pitch
+// @Summary Get delivery location
+// @Description Get a delivery location of the festival
+// @Tags deliveries
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param locationId path string true "Location ID" format(uuid)
+// @Success 200 {object} response.Response{data=Location} "Delivery location"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Location not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/delivery-locations/{locationId} [get]
+func (h *Handler) GetLocation(c *gin.Context) {
+	festivalID, locationID, ok := locationParams(c)
+	if !ok {
+		return
+	}
+
+	location, err := h.service.GetLocation(c.Request.Context(), festivalID, locationID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, location)
+}
+
+// UpdateLocation changes a table or pitch
+// @Summary Update delivery location
+// @Description Change the stand, name, zone, SLA or status of a delivery location. rotateQr revokes the printed QR codes of the location.
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param locationId path string true "Location ID" format(uuid)
+// @Param request body UpdateLocationRequest true "Changes"
+// @Success 200 {object} response.Response{data=Location} "Updated location"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Location not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/delivery-locations/{locationId} [patch]
+func (h *Handler) UpdateLocation(c *gin.Context) {
+	festivalID, locationID, ok := locationParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	location, err := h.service.UpdateLocation(c.Request.Context(), festivalID, locationID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, location)
+}
+
+// GetLocationQR returns the QR code of a table or pitch
+// @Summary Get delivery location QR code
+// @Description Get the signed QR code of a delivery location as a PNG image to print, or its payload with format=json
+// @Tags deliveries
+// @Produce png
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param locationId path string true "Location ID" format(uuid)
+// @Param format query string false "png or json" Enums(png, json) default(png)
+// @Success 200 {file} binary "QR code image"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Location not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/delivery-locations/{locationId}/qr [get]
+func (h *Handler) GetLocationQR(c *gin.Context) {
+	festivalID, locationID, ok := locationParams(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("format") == "json" {
+		qr, err := h.service.GetLocationQR(c.Request.Context(), festivalID, locationID)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		response.OK(c, qr)
+		return
+	}
+
+	png, location, err := h.service.RenderLocationQR(c.Request.Context(), festivalID, locationID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+location.Code+`.png"`)
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// Resolve looks up a scanned QR code or typed location code
+// @Summary Resolve delivery location
+// @Description Look up the table or pitch of a scanned QR code or printed code, before ordering to it
+// @Tags deliveries
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param code query string true "QR code payload or printed location code"
+// @Success 200 {object} response.Response{data=LocationInfo} "Delivery location"
+// @Failure 400 {object} response.ErrorResponse "Invalid or revoked QR code"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Location not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/delivery-locations/resolve [get]
+func (h *Handler) Resolve(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	info, err := h.service.Resolve(c.Request.Context(), festivalID, c.Query("code"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, info)
+}
+
+// GetQueue returns the delivery queue of the runners
+// @Summary Get delivery queue
+// @Description List the paid orders waiting for a runner or being delivered, the closest deadline first, with overdue deliveries flagged
+// @Tags deliveries
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the orders of this stand" format(uuid)
+// @Param zone query string false "Only the locations of this zone"
+// @Param status query string false "Only this delivery status" Enums(WAITING, ASSIGNED)
+// @Param mine query bool false "Only the deliveries of the caller"
+// @Success 200 {object} response.Response{data=Queue} "Delivery queue"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/deliveries [get]
+func (h *Handler) GetQueue(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	filter := QueueFilter{Zone: c.Query("zone")}
+	if raw := c.Query("standId"); raw != "" {
+		standID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+			return
+		}
+		filter.StandID = &standID
+	}
+	if raw := c.Query("status"); raw != "" {
+		status := order.DeliveryStatus(raw)
+		if status != order.DeliveryStatusWaiting && status != order.DeliveryStatusAssigned {
+			h.handleError(c, ErrInvalidStatus)
+			return
+		}
+		filter.Status = &status
+	}
+	if c.Query("mine") == "true" {
+		runnerID, ok := runnerParam(c)
+		if !ok {
+			return
+		}
+		filter.RunnerID = &runnerID
+	}
+
+	queue, err := h.service.Queue(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, queue)
+}
+
+// Assign gives a delivery to a runner
+// @Summary Assign delivery
+// @Description Take a delivery, or hand it to another runner with runnerId. Orders taken by another runner can only be handed over with runnerId.
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param orderId path string true "Order ID" format(uuid)
+// @Param request body AssignRequest false "Runner"
+// @Success 200 {object} response.Response{data=QueueEntry} "Assigned delivery"
+// @Failure 400 {object} response.ErrorResponse "Order not for delivery"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Taken by another runner or already delivered"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/deliveries/{orderId}/assign [post]
+func (h *Handler) Assign(c *gin.Context) {
+	festivalID, orderID, ok := orderParams(c)
+	if !ok {
+		return
+	}
+	callerID, ok := runnerParam(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	entry, err := h.service.Assign(c.Request.Context(), festivalID, orderID, callerID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, entry)
+}
+
+// ConfirmDelivered confirms a delivery
+// @Summary Confirm delivery
+// @Description Record that the calling runner handed the order over at its table or pitch. Waiting orders can be delivered without being taken first.
+// @Tags deliveries
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param orderId path string true "Order ID" format(uuid)
+// @Success 200 {object} response.Response{data=QueueEntry} "Delivered order"
+// @Failure 400 {object} response.ErrorResponse "Order not for delivery"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Taken by another runner or already delivered"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/deliveries/{orderId}/delivered [post]
+func (h *Handler) ConfirmDelivered(c *gin.Context) {
+	festivalID, orderID, ok := orderParams(c)
+	if !ok {
+		return
+	}
+	runnerID, ok := runnerParam(c)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.ConfirmDelivered(c.Request.Context(), festivalID, orderID, runnerID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, entry)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func locationParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	locationID, err := uuid.Parse(c.Param("locationId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid location ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, locationID, true
+}
+
+func orderParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	orderID, err := uuid.Parse(c.Param("orderId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid order ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, orderID, true
+}
+
+// runnerParam returns the calling staff member
+func runnerParam(c *gin.Context) (uuid.UUID, bool) {
+	runnerID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return uuid.Nil, false
+	}
+	return runnerID, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrLocationNotFound):
+		response.NotFound(c, "Delivery location not found")
+	case errors.Is(err, apperrors.ErrNotFound):
+		response.NotFound(c, "Order not found")
+	case errors.Is(err, ErrCodeTaken):
+		response.Conflict(c, "LOCATION_CODE_TAKEN", err.Error())
+	case errors.Is(err, ErrInvalidQRCode):
+		response.BadRequest(c, "INVALID_QR_CODE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidStatus):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, order.ErrNotForDelivery), errors.Is(err, order.ErrDeliveryNotPaid):
+		response.BadRequest(c, "NOT_DELIVERABLE", err.Error(), nil)
+	case errors.Is(err, order.ErrAlreadyDelivered):
+		response.Conflict(c, "ALREADY_DELIVERED", err.Error())
+	case errors.Is(err, order.ErrDeliveryTaken):
+		response.Conflict(c, "DELIVERY_TAKEN", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
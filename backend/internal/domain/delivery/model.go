@@ -0,0 +1,159 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+)
+
+// Delivery errors
+var (
+	ErrLocationNotFound = errors.New("delivery location not found")
+	ErrCodeTaken        = errors.New("another location of the festival has this code")
+	ErrInvalidQRCode    = errors.New("invalid or revoked location QR code")
+	ErrInvalidStatus    = errors.New("status must be WAITING or ASSIGNED")
+)
+
+// DefaultSLAMinutes is the time allowed from order to delivery when a location does not
+// set its own
+const DefaultSLAMinutes = 20
+
+// LocationKind is the kind of place orders are delivered to
+type LocationKind string
+
+const (
+	LocationKindTable LocationKind = "TABLE" // Numbered table in a bar or food court
+	LocationKindPitch LocationKind = "PITCH" // Camping pitch
+)
+
+// Location is a numbered table or camping pitch runners deliver orders to. Attendees
+// order to it by scanning its QR code or typing the code printed next to it.
+type Location struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    *uuid.UUID   `json:"standId,omitempty" gorm:"type:uuid"` // Only stand delivering here, any stand when nil
+	Kind       LocationKind `json:"kind" gorm:"not null"`
+	Code       string       `json:"code" gorm:"not null"` // Printed code, unique within the festival
+	Name       string       `json:"name,omitempty"`
+	Zone       string       `json:"zone,omitempty"` // Area runners group deliveries by
+	SLAMinutes int          `json:"slaMinutes" gorm:"column:sla_minutes;not null"`
+	QRVersion  int          `json:"qrVersion" gorm:"column:qr_version;not null"` // Bumped to revoke printed QR codes
+	Active     bool         `json:"active" gorm:"not null"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+}
+
+func (Location) TableName() string {
+	return "delivery_locations"
+}
+
+// Label is how the location is shown on orders and to runners
+func (l *Location) Label() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	if l.Kind == LocationKindPitch {
+		return fmt.Sprintf("Pitch %s", l.Code)
+	}
+	return fmt.Sprintf("Table %s", l.Code)
+}
+
+// SLA is the time allowed from order to delivery at the location
+func (l *Location) SLA() time.Duration {
+	return time.Duration(l.SLAMinutes) * time.Minute
+}
+
+// LocationInfo is what attendees see of a scanned location
+type LocationInfo struct {
+	ID         uuid.UUID    `json:"id"`
+	Kind       LocationKind `json:"kind"`
+	Code       string       `json:"code"`
+	Label      string       `json:"label"`
+	Zone       string       `json:"zone,omitempty"`
+	StandID    *uuid.UUID   `json:"standId,omitempty"`
+	SLAMinutes int          `json:"slaMinutes"`
+}
+
+// QRPayload is the data encoded in the QR code of a location
+type QRPayload struct {
+	LocationID uuid.UUID `json:"l"`
+	FestivalID uuid.UUID `json:"f"`
+	Version    int       `json:"v"`
+	Signature  string    `json:"s"`
+}
+
+// LocationQR is the QR code payload of a location, to print or encode in a link
+type LocationQR struct {
+	LocationID uuid.UUID `json:"locationId"`
+	Code       string    `json:"code"`
+	Label      string    `json:"label"`
+	Payload    string    `json:"payload"` // Pass as deliveryLocation when ordering
+	Version    int       `json:"version"`
+}
+
+// QueueEntry is an order in the delivery queue of the runners
+type QueueEntry struct {
+	OrderID     uuid.UUID            `json:"orderId"`
+	StandID     uuid.UUID            `json:"standId"`
+	LocationID  *uuid.UUID           `json:"locationId,omitempty"`
+	Location    string               `json:"location"`
+	Zone        string               `json:"zone,omitempty"`
+	Items       order.OrderItems     `json:"items"`
+	TotalAmount int64                `json:"totalAmount"`
+	Notes       string               `json:"notes,omitempty"`
+	Status      order.DeliveryStatus `json:"status"`
+	RunnerID    *uuid.UUID           `json:"runnerId,omitempty"`
+	OrderedAt   time.Time            `json:"orderedAt"`
+	ReadyAt     *time.Time           `json:"readyAt,omitempty"` // Nil while the stand is preparing it
+	AssignedAt  *time.Time           `json:"assignedAt,omitempty"`
+	DueAt       *time.Time           `json:"dueAt,omitempty"`
+	MinutesLeft int                  `json:"minutesLeft"` // Negative once overdue
+	Overdue     bool                 `json:"overdue"`
+}
+
+// Queue is the delivery queue of a festival, the closest deadline first
+type Queue struct {
+	Waiting     int          `json:"waiting"`
+	Assigned    int          `json:"assigned"`
+	Overdue     int          `json:"overdue"`
+	Entries     []QueueEntry `json:"entries"`
+	GeneratedAt time.Time    `json:"generatedAt"`
+}
+
+// QueueFilter narrows the delivery queue
+type QueueFilter struct {
+	StandID  *uuid.UUID
+	RunnerID *uuid.UUID
+	Status   *order.DeliveryStatus
+	Zone     string
+}
+
+// CreateLocationRequest adds a table or pitch
+type CreateLocationRequest struct {
+	StandID    *uuid.UUID   `json:"standId,omitempty"`
+	Kind       LocationKind `json:"kind" binding:"required,oneof=TABLE PITCH"`
+	Code       string       `json:"code" binding:"required,max=20"`
+	Name       string       `json:"name,omitempty" binding:"max=100"`
+	Zone       string       `json:"zone,omitempty" binding:"max=100"`
+	SLAMinutes *int         `json:"slaMinutes,omitempty" binding:"omitempty,min=1,max=240"`
+}
+
+// UpdateLocationRequest changes a table or pitch. Setting RotateQR revokes its printed
+// QR codes.
+type UpdateLocationRequest struct {
+	StandID    *uuid.UUID `json:"standId,omitempty"`
+	AnyStand   bool       `json:"anyStand,omitempty"` // Let every stand deliver to the location
+	Name       *string    `json:"name,omitempty" binding:"omitempty,max=100"`
+	Zone       *string    `json:"zone,omitempty" binding:"omitempty,max=100"`
+	SLAMinutes *int       `json:"slaMinutes,omitempty" binding:"omitempty,min=1,max=240"`
+	Active     *bool      `json:"active,omitempty"`
+	RotateQR   bool       `json:"rotateQr,omitempty"`
+}
+
+// AssignRequest gives a delivery to a runner. Without a runner the caller takes it.
+type AssignRequest struct {
+	RunnerID *uuid.UUID `json:"runnerId,omitempty"`
+}
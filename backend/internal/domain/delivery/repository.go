@@ -0,0 +1,90 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateLocation(ctx context.Context, location *Location) error
+	UpdateLocation(ctx context.Context, location *Location) error
+	GetLocation(ctx context.Context, festivalID, id uuid.UUID) (*Location, error)
+	GetLocationByCode(ctx context.Context, festivalID uuid.UUID, code string) (*Location, error)
+	ListLocations(ctx context.Context, festivalID uuid.UUID, kind *LocationKind) ([]Location, error)
+	GetLocationsByIDs(ctx context.Context, ids []uuid.UUID) ([]Location, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateLocation(ctx context.Context, location *Location) error {
+	if err := r.db.WithContext(ctx).Create(location).Error; err != nil {
+		return fmt.Errorf("failed to create delivery location: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateLocation(ctx context.Context, location *Location) error {
+	if err := r.db.WithContext(ctx).Save(location).Error; err != nil {
+		return fmt.Errorf("failed to update delivery location: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetLocation(ctx context.Context, festivalID, id uuid.UUID) (*Location, error) {
+	var location Location
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&location).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get delivery location: %w", err)
+	}
+	return &location, nil
+}
+
+func (r *repository) GetLocationByCode(ctx context.Context, festivalID uuid.UUID, code string) (*Location, error) {
+	var location Location
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND code = ?", festivalID, code).First(&location).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get delivery location: %w", err)
+	}
+	return &location, nil
+}
+
+func (r *repository) ListLocations(ctx context.Context, festivalID uuid.UUID, kind *LocationKind) ([]Location, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if kind != nil {
+		query = query.Where("kind = ?", *kind)
+	}
+
+	var locations []Location
+	if err := query.Order("kind, zone, code").Find(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list delivery locations: %w", err)
+	}
+	return locations, nil
+}
+
+func (r *repository) GetLocationsByIDs(ctx context.Context, ids []uuid.UUID) ([]Location, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var locations []Location
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get delivery locations: %w", err)
+	}
+	return locations, nil
+}
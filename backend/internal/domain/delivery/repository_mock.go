@@ -0,0 +1,56 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateLocation(ctx context.Context, location *Location) error {
+	args := m.Called(ctx, location)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateLocation(ctx context.Context, location *Location) error {
+	args := m.Called(ctx, location)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetLocation(ctx context.Context, festivalID, id uuid.UUID) (*Location, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Location), args.Error(1)
+}
+
+func (m *MockRepository) GetLocationByCode(ctx context.Context, festivalID uuid.UUID, code string) (*Location, error) {
+	args := m.Called(ctx, festivalID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Location), args.Error(1)
+}
+
+func (m *MockRepository) ListLocations(ctx context.Context, festivalID uuid.UUID, kind *LocationKind) ([]Location, error) {
+	args := m.Called(ctx, festivalID, kind)
+	return args.Get(0).([]Location), args.Error(1)
+}
+
+func (m *MockRepository) GetLocationsByIDs(ctx context.Context, ids []uuid.UUID) ([]Location, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]Location), args.Error(1)
+}
@@ -0,0 +1,421 @@
+package delivery
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+)
+
+// OrderDeliveries runs the delivery of orders; satisfied by order.Service
+type OrderDeliveries interface {
+	DeliveryQueue(ctx context.Context, festivalID uuid.UUID, filter order.DeliveryQueueFilter) ([]order.Order, error)
+	AssignDelivery(ctx context.Context, festivalID, orderID, runnerID uuid.UUID, reassign bool) (*order.Order, error)
+	ConfirmDelivery(ctx context.Context, festivalID, orderID, runnerID uuid.UUID) (*order.Order, error)
+}
+
+// QRGenerator renders QR code images; satisfied by qrcode.Generator
+type QRGenerator interface {
+	GenerateQRFromData(encodedPayload string) ([]byte, error)
+}
+
+type Service struct {
+	repo   Repository
+	orders OrderDeliveries
+	qr     QRGenerator
+	secret []byte
+	now    func() time.Time
+}
+
+// NewService creates the delivery service. Location QR codes are signed with secret so
+// that attendees cannot order to a location by forging one.
+func NewService(repo Repository, orders OrderDeliveries, qr QRGenerator, secret []byte) *Service {
+	return &Service{
+		repo:   repo,
+		orders: orders,
+		qr:     qr,
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+// CreateLocation adds a table or pitch to the festival
+func (s *Service) CreateLocation(ctx context.Context, festivalID uuid.UUID, req CreateLocationRequest) (*Location, error) {
+	code := normalizeCode(req.Code)
+	existing, err := s.repo.GetLocationByCode(ctx, festivalID, code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCodeTaken
+	}
+
+	now := s.now()
+	location := &Location{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    req.StandID,
+		Kind:       req.Kind,
+		Code:       code,
+		Name:       strings.TrimSpace(req.Name),
+		Zone:       strings.TrimSpace(req.Zone),
+		SLAMinutes: DefaultSLAMinutes,
+		QRVersion:  1,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if req.SLAMinutes != nil {
+		location.SLAMinutes = *req.SLAMinutes
+	}
+
+	if err := s.repo.CreateLocation(ctx, location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// UpdateLocation changes a table or pitch
+func (s *Service) UpdateLocation(ctx context.Context, festivalID, id uuid.UUID, req UpdateLocationRequest) (*Location, error) {
+	location, err := s.GetLocation(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StandID != nil {
+		location.StandID = req.StandID
+	} else if req.AnyStand {
+		location.StandID = nil
+	}
+	if req.Name != nil {
+		location.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Zone != nil {
+		location.Zone = strings.TrimSpace(*req.Zone)
+	}
+	if req.SLAMinutes != nil {
+		location.SLAMinutes = *req.SLAMinutes
+	}
+	if req.Active != nil {
+		location.Active = *req.Active
+	}
+	if req.RotateQR {
+		location.QRVersion++
+	}
+	location.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateLocation(ctx, location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// GetLocation returns a table or pitch of the festival
+func (s *Service) GetLocation(ctx context.Context, festivalID, id uuid.UUID) (*Location, error) {
+	location, err := s.repo.GetLocation(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, ErrLocationNotFound
+	}
+	return location, nil
+}
+
+// ListLocations lists the tables and pitches of the festival
+func (s *Service) ListLocations(ctx context.Context, festivalID uuid.UUID, kind *LocationKind) ([]Location, error) {
+	locations, err := s.repo.ListLocations(ctx, festivalID, kind)
+	if err != nil {
+		return nil, err
+	}
+	if locations == nil {
+		locations = []Location{}
+	}
+	return locations, nil
+}
+
+// GetLocationQR returns the signed QR code payload of a location
+func (s *Service) GetLocationQR(ctx context.Context, festivalID, id uuid.UUID) (*LocationQR, error) {
+	location, err := s.GetLocation(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := s.encodeQR(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocationQR{
+		LocationID: location.ID,
+		Code:       location.Code,
+		Label:      location.Label(),
+		Payload:    payload,
+		Version:    location.QRVersion,
+	}, nil
+}
+
+// RenderLocationQR renders the QR code of a location as a PNG image, to print and
+// stick on the table or pitch marker
+func (s *Service) RenderLocationQR(ctx context.Context, festivalID, id uuid.UUID) ([]byte, *Location, error) {
+	location, err := s.GetLocation(ctx, festivalID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := s.encodeQR(location)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	png, err := s.qr.GenerateQRFromData(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return png, location, nil
+}
+
+// Resolve returns the active location a scanned QR code or typed code points to
+func (s *Service) Resolve(ctx context.Context, festivalID uuid.UUID, ref string) (*LocationInfo, error) {
+	location, err := s.resolve(ctx, festivalID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocationInfo{
+		ID:         location.ID,
+		Kind:       location.Kind,
+		Code:       location.Code,
+		Label:      location.Label(),
+		Zone:       location.Zone,
+		StandID:    location.StandID,
+		SLAMinutes: location.SLAMinutes,
+	}, nil
+}
+
+// ResolveDeliveryLocation resolves the delivery location of a new order, or nil when
+// the reference matches no active location of the festival
+func (s *Service) ResolveDeliveryLocation(ctx context.Context, festivalID uuid.UUID, ref string) (*order.DeliveryTarget, error) {
+	location, err := s.resolve(ctx, festivalID, ref)
+	if err != nil {
+		if errors.Is(err, ErrLocationNotFound) || errors.Is(err, ErrInvalidQRCode) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order.DeliveryTarget{
+		LocationID: location.ID,
+		StandID:    location.StandID,
+		Label:      location.Label(),
+		SLA:        location.SLA(),
+	}, nil
+}
+
+// Queue returns the orders runners have to deliver, the closest deadline first
+func (s *Service) Queue(ctx context.Context, festivalID uuid.UUID, filter QueueFilter) (*Queue, error) {
+	orders, err := s.orders.DeliveryQueue(ctx, festivalID, order.DeliveryQueueFilter{
+		StandID:  filter.StandID,
+		RunnerID: filter.RunnerID,
+		Status:   filter.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := s.locationZones(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	queue := &Queue{Entries: make([]QueueEntry, 0, len(orders)), GeneratedAt: now}
+	for i := range orders {
+		entry := buildQueueEntry(&orders[i], zones, now)
+		if filter.Zone != "" && !strings.EqualFold(entry.Zone, filter.Zone) {
+			continue
+		}
+
+		switch entry.Status {
+		case order.DeliveryStatusWaiting:
+			queue.Waiting++
+		case order.DeliveryStatusAssigned:
+			queue.Assigned++
+		}
+		if entry.Overdue {
+			queue.Overdue++
+		}
+		queue.Entries = append(queue.Entries, entry)
+	}
+	return queue, nil
+}
+
+// Assign gives a delivery to a runner. Runners take deliveries for themselves; passing
+// another runner hands the delivery over even if it was already taken.
+func (s *Service) Assign(ctx context.Context, festivalID, orderID, callerID uuid.UUID, req AssignRequest) (*QueueEntry, error) {
+	runnerID, reassign := callerID, false
+	if req.RunnerID != nil {
+		runnerID, reassign = *req.RunnerID, true
+	}
+
+	o, err := s.orders.AssignDelivery(ctx, festivalID, orderID, runnerID, reassign)
+	if err != nil {
+		return nil, err
+	}
+	return s.entry(ctx, o)
+}
+
+// ConfirmDelivered records that the runner handed the order over at its location
+func (s *Service) ConfirmDelivered(ctx context.Context, festivalID, orderID, runnerID uuid.UUID) (*QueueEntry, error) {
+	o, err := s.orders.ConfirmDelivery(ctx, festivalID, orderID, runnerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.entry(ctx, o)
+}
+
+// resolve finds the location of a QR code payload or of a printed code
+func (s *Service) resolve(ctx context.Context, festivalID uuid.UUID, ref string) (*Location, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, ErrLocationNotFound
+	}
+
+	payload, isQR := decodeQR(ref)
+	if !isQR {
+		location, err := s.repo.GetLocationByCode(ctx, festivalID, normalizeCode(ref))
+		if err != nil {
+			return nil, err
+		}
+		if location == nil || !location.Active {
+			return nil, ErrLocationNotFound
+		}
+		return location, nil
+	}
+
+	if payload.FestivalID != festivalID || !hmac.Equal([]byte(payload.Signature), []byte(s.sign(*payload))) {
+		return nil, ErrInvalidQRCode
+	}
+	location, err := s.repo.GetLocation(ctx, festivalID, payload.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil || location.QRVersion != payload.Version {
+		return nil, ErrInvalidQRCode
+	}
+	if !location.Active {
+		return nil, ErrLocationNotFound
+	}
+	return location, nil
+}
+
+func (s *Service) encodeQR(location *Location) (string, error) {
+	payload := QRPayload{
+		LocationID: location.ID,
+		FestivalID: location.FestivalID,
+		Version:    location.QRVersion,
+	}
+	payload.Signature = s.sign(payload)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal QR payload: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// sign computes the signature of a QR payload over "<l>:<f>:<v>"
+func (s *Service) sign(payload QRPayload) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s:%s:%d", payload.LocationID, payload.FestivalID, payload.Version)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodeQR decodes a QR code payload. Printed codes are short and never decode to one.
+func decodeQR(ref string) (*QRPayload, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(ref)
+	if err != nil {
+		return nil, false
+	}
+	var payload QRPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.LocationID == uuid.Nil {
+		return nil, false
+	}
+	return &payload, true
+}
+
+// entry builds the queue entry of a single order
+func (s *Service) entry(ctx context.Context, o *order.Order) (*QueueEntry, error) {
+	zones, err := s.locationZones(ctx, []order.Order{*o})
+	if err != nil {
+		return nil, err
+	}
+	entry := buildQueueEntry(o, zones, s.now())
+	return &entry, nil
+}
+
+// locationZones returns the zone of the delivery location of each order
+func (s *Service) locationZones(ctx context.Context, orders []order.Order) (map[uuid.UUID]string, error) {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, o := range orders {
+		if o.DeliveryLocationID != nil && !seen[*o.DeliveryLocationID] {
+			seen[*o.DeliveryLocationID] = true
+			ids = append(ids, *o.DeliveryLocationID)
+		}
+	}
+
+	locations, err := s.repo.GetLocationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make(map[uuid.UUID]string, len(locations))
+	for _, l := range locations {
+		zones[l.ID] = l.Zone
+	}
+	return zones, nil
+}
+
+func buildQueueEntry(o *order.Order, zones map[uuid.UUID]string, now time.Time) QueueEntry {
+	entry := QueueEntry{
+		OrderID:     o.ID,
+		StandID:     o.StandID,
+		LocationID:  o.DeliveryLocationID,
+		Location:    o.DeliveryLocation,
+		Items:       o.Items,
+		TotalAmount: o.TotalAmount,
+		Notes:       o.Notes,
+		RunnerID:    o.RunnerID,
+		OrderedAt:   o.CreatedAt,
+		ReadyAt:     o.ReadyAt,
+		AssignedAt:  o.AssignedAt,
+		DueAt:       o.DeliveryDueAt,
+	}
+	if o.DeliveryStatus != nil {
+		entry.Status = *o.DeliveryStatus
+	}
+	if o.DeliveryLocationID != nil {
+		entry.Zone = zones[*o.DeliveryLocationID]
+	}
+	if o.DeliveryDueAt != nil && entry.Status != order.DeliveryStatusDelivered {
+		left := o.DeliveryDueAt.Sub(now)
+		entry.MinutesLeft = int(math.Floor(left.Minutes()))
+		entry.Overdue = left < 0
+	}
+	return entry
+}
+
+// normalizeCode makes printed codes case-insensitive
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,225 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(repo Repository, orders order.Repository) *Service {
+	return NewService(repo, order.NewService(orders, nil, nil), nil, []byte("test-secret"))
+}
+
+// expectOrder serves a copy of o on the next read of the order
+func expectOrder(orders *order.MockRepository, o order.Order) {
+	orders.On("GetOrderByID", mock.Anything, o.ID).Return(&o, nil).Once()
+}
+
+// deliveryUpdate matches the order saved by a delivery update
+func deliveryUpdate(status order.DeliveryStatus, runnerID uuid.UUID) interface{} {
+	return mock.MatchedBy(func(o *order.Order) bool {
+		return *o.DeliveryStatus == status && *o.RunnerID == runnerID
+	})
+}
+
+func TestLocationQR(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo, order.NewMockRepository())
+	festivalID := uuid.New()
+
+	stored := &Location{}
+	mockRepo.On("GetLocationByCode", mock.Anything, festivalID, "T12").Return(nil, nil).Once()
+	mockRepo.On("CreateLocation", mock.Anything, mock.AnythingOfType("*delivery.Location")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Location) }).
+		Return(nil).Once()
+	location, err := service.CreateLocation(ctx, festivalID, CreateLocationRequest{Kind: LocationKindTable, Code: " t12 ", Zone: "Food court"})
+	require.NoError(t, err)
+	assert.Equal(t, "T12", location.Code)
+	assert.Equal(t, "Table T12", location.Label())
+	assert.Equal(t, DefaultSLAMinutes, location.SLAMinutes)
+
+	mockRepo.On("GetLocationByCode", mock.Anything, festivalID, "T12").Return(stored, nil)
+	_, err = service.CreateLocation(ctx, festivalID, CreateLocationRequest{Kind: LocationKindPitch, Code: "T12"})
+	assert.ErrorIs(t, err, ErrCodeTaken)
+	mockRepo.AssertNumberOfCalls(t, "CreateLocation", 1)
+
+	mockRepo.On("GetLocation", mock.Anything, festivalID, location.ID).Return(stored, nil)
+	qr, err := service.GetLocationQR(ctx, festivalID, location.ID)
+	require.NoError(t, err)
+
+	info, err := service.Resolve(ctx, festivalID, qr.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, location.ID, info.ID)
+	assert.Equal(t, "Food court", info.Zone)
+
+	// The printed code resolves too, whatever its case
+	info, err = service.Resolve(ctx, festivalID, "t12")
+	require.NoError(t, err)
+	assert.Equal(t, location.ID, info.ID)
+
+	// QR codes do not work at another festival
+	_, err = service.Resolve(ctx, uuid.New(), qr.Payload)
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+
+	// Rotating the QR code revokes the printed one
+	mockRepo.On("UpdateLocation", mock.Anything, stored).Return(nil).Twice()
+	_, err = service.UpdateLocation(ctx, festivalID, location.ID, UpdateLocationRequest{RotateQR: true})
+	require.NoError(t, err)
+	_, err = service.Resolve(ctx, festivalID, qr.Payload)
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+
+	rotated, err := service.GetLocationQR(ctx, festivalID, location.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.Version)
+	_, err = service.Resolve(ctx, festivalID, rotated.Payload)
+	assert.NoError(t, err)
+
+	// Inactive locations cannot be ordered to
+	active := false
+	_, err = service.UpdateLocation(ctx, festivalID, location.ID, UpdateLocationRequest{Active: &active})
+	require.NoError(t, err)
+	_, err = service.Resolve(ctx, festivalID, rotated.Payload)
+	assert.ErrorIs(t, err, ErrLocationNotFound)
+	_, err = service.Resolve(ctx, festivalID, "T12")
+	assert.ErrorIs(t, err, ErrLocationNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestResolveDeliveryLocation(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo, order.NewMockRepository())
+	festivalID := uuid.New()
+	standID := uuid.New()
+
+	location := &Location{
+		ID: uuid.New(), FestivalID: festivalID, StandID: &standID, Kind: LocationKindPitch,
+		Code: "C-204", Name: "Camping C, pitch 204", SLAMinutes: 10, QRVersion: 1, Active: true,
+	}
+	mockRepo.On("GetLocationByCode", mock.Anything, festivalID, "C-204").Return(location, nil)
+	mockRepo.On("GetLocationByCode", mock.Anything, festivalID, "C-999").Return(nil, nil)
+
+	target, err := service.ResolveDeliveryLocation(ctx, festivalID, "c-204")
+	require.NoError(t, err)
+	require.NotNil(t, target)
+	assert.Equal(t, location.ID, target.LocationID)
+	assert.Equal(t, &standID, target.StandID)
+	assert.Equal(t, "Camping C, pitch 204", target.Label)
+	assert.Equal(t, 10*time.Minute, target.SLA)
+
+	target, err = service.ResolveDeliveryLocation(ctx, festivalID, "C-999")
+	require.NoError(t, err)
+	assert.Nil(t, target)
+
+	// A QR code of another festival is no location either
+	qr, err := service.encodeQR(location)
+	require.NoError(t, err)
+	target, err = service.ResolveDeliveryLocation(ctx, uuid.New(), qr)
+	require.NoError(t, err)
+	assert.Nil(t, target)
+}
+
+func TestAssignAndConfirmDelivery(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := NewMockRepository()
+	orders := order.NewMockRepository()
+	service := newTestService(mockRepo, orders)
+	festivalID := uuid.New()
+	runnerA, runnerB := uuid.New(), uuid.New()
+	now := time.Date(2026, 7, 18, 20, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	locationID := uuid.New()
+	mockRepo.On("GetLocationsByIDs", mock.Anything, []uuid.UUID{locationID}).
+		Return([]Location{{ID: locationID, Zone: "Food court"}}, nil)
+
+	waiting, assigned, delivered := order.DeliveryStatusWaiting, order.DeliveryStatusAssigned, order.DeliveryStatusDelivered
+	due := now.Add(-5 * time.Minute)
+	o := order.Order{
+		ID:                 uuid.New(),
+		FestivalID:         festivalID,
+		Status:             order.OrderStatusPaid,
+		DeliveryLocationID: &locationID,
+		DeliveryStatus:     &waiting,
+		DeliveryDueAt:      &due,
+		CreatedAt:          now.Add(-25 * time.Minute),
+	}
+
+	orders.On("GetDeliveryQueue", mock.Anything, festivalID, order.DeliveryQueueFilter{}).Return([]order.Order{o}, nil).Once()
+	queue, err := service.Queue(ctx, festivalID, QueueFilter{})
+	require.NoError(t, err)
+	require.Len(t, queue.Entries, 1)
+	assert.Equal(t, 1, queue.Waiting)
+	assert.Equal(t, 1, queue.Overdue)
+	assert.Equal(t, -5, queue.Entries[0].MinutesLeft)
+	assert.Equal(t, "Food court", queue.Entries[0].Zone)
+
+	// Another runner took it between the read and the update
+	expectOrder(orders, o)
+	orders.On("UpdateDelivery", mock.Anything, deliveryUpdate(assigned, runnerB), waiting, (*uuid.UUID)(nil)).Return(false, nil).Once()
+	_, err = service.Assign(ctx, festivalID, o.ID, runnerB, AssignRequest{})
+	assert.ErrorIs(t, err, order.ErrDeliveryTaken)
+
+	expectOrder(orders, o)
+	orders.On("UpdateDelivery", mock.Anything, deliveryUpdate(assigned, runnerA), waiting, (*uuid.UUID)(nil)).Return(true, nil).Once()
+	entry, err := service.Assign(ctx, festivalID, o.ID, runnerA, AssignRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, order.DeliveryStatusAssigned, entry.Status)
+	assert.Equal(t, &runnerA, entry.RunnerID)
+	o.DeliveryStatus, o.RunnerID = &assigned, &runnerA
+
+	// Another runner cannot take it, but it can be handed over explicitly
+	expectOrder(orders, o)
+	_, err = service.Assign(ctx, festivalID, o.ID, runnerB, AssignRequest{})
+	assert.ErrorIs(t, err, order.ErrDeliveryTaken)
+	expectOrder(orders, o)
+	orders.On("UpdateDelivery", mock.Anything, deliveryUpdate(assigned, runnerB), assigned, &runnerA).Return(true, nil).Once()
+	_, err = service.Assign(ctx, festivalID, o.ID, runnerA, AssignRequest{RunnerID: &runnerB})
+	require.NoError(t, err)
+	o.RunnerID = &runnerB
+
+	expectOrder(orders, o)
+	_, err = service.ConfirmDelivered(ctx, festivalID, o.ID, runnerA)
+	assert.ErrorIs(t, err, order.ErrDeliveryTaken)
+
+	expectOrder(orders, o)
+	orders.On("UpdateDelivery", mock.Anything, deliveryUpdate(delivered, runnerB), assigned, &runnerB).Return(true, nil).Once()
+	entry, err = service.ConfirmDelivered(ctx, festivalID, o.ID, runnerB)
+	require.NoError(t, err)
+	assert.Equal(t, order.DeliveryStatusDelivered, entry.Status)
+	assert.False(t, entry.Overdue)
+	o.DeliveryStatus = &delivered
+
+	// Confirming twice is harmless, taking a delivered order is not
+	expectOrder(orders, o)
+	_, err = service.ConfirmDelivered(ctx, festivalID, o.ID, runnerB)
+	assert.NoError(t, err)
+	expectOrder(orders, o)
+	_, err = service.Assign(ctx, festivalID, o.ID, runnerA, AssignRequest{})
+	assert.ErrorIs(t, err, order.ErrAlreadyDelivered)
+
+	orders.AssertNumberOfCalls(t, "UpdateDelivery", 4)
+	orders.AssertExpectations(t)
+}
+
+func TestConfirmDelivery_PickupOrder(t *testing.T) {
+	ctx := context.Background()
+	orders := order.NewMockRepository()
+	service := newTestService(NewMockRepository(), orders)
+	festivalID := uuid.New()
+
+	o := order.Order{ID: uuid.New(), FestivalID: festivalID, Status: order.OrderStatusPaid}
+	expectOrder(orders, o)
+
+	_, err := service.ConfirmDelivered(ctx, festivalID, o.ID, uuid.New())
+	assert.ErrorIs(t, err, order.ErrNotForDelivery)
+	orders.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,386 @@
+package demo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/domain/stats"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+)
+
+// catalogStand is a stand of the demo festival
+type catalogStand struct {
+	name     string
+	category stand.StandCategory
+	location string
+	products []catalogProduct
+}
+
+// catalogProduct is a product of a demo stand
+type catalogProduct struct {
+	name      string
+	category  product.ProductCategory
+	price     int64 // In cents
+	stock     int   // 0 = unlimited
+	pairsWith int   // Index of the product often bought with this one, -1 for none
+}
+
+var catalog = []catalogStand{
+	{name: "Main Bar", category: stand.StandCategoryBar, location: "Main stage", products: []catalogProduct{
+		{name: "Pils", category: product.ProductCategoryBeer, price: 400, pairsWith: -1},
+		{name: "IPA", category: product.ProductCategoryBeer, price: 550, pairsWith: -1},
+		{name: "Cola", category: product.ProductCategorySoft, price: 300, pairsWith: -1},
+		{name: "Water", category: product.ProductCategorySoft, price: 250, pairsWith: -1},
+		{name: "Crisps", category: product.ProductCategorySnack, price: 300, pairsWith: 0},
+	}},
+	{name: "Cocktail Lounge", category: stand.StandCategoryBar, location: "Chill-out area", products: []catalogProduct{
+		{name: "Mojito", category: product.ProductCategoryCocktail, price: 900, pairsWith: -1},
+		{name: "Spritz", category: product.ProductCategoryCocktail, price: 800, pairsWith: -1},
+		{name: "Virgin Mojito", category: product.ProductCategoryCocktail, price: 600, pairsWith: -1},
+		{name: "Nachos", category: product.ProductCategorySnack, price: 500, pairsWith: 0},
+	}},
+	{name: "Burger Truck", category: stand.StandCategoryFood, location: "Food court", products: []catalogProduct{
+		{name: "Classic Burger", category: product.ProductCategoryFood, price: 1100, pairsWith: 2},
+		{name: "Veggie Burger", category: product.ProductCategoryFood, price: 1100, pairsWith: 2},
+		{name: "Fries", category: product.ProductCategoryFood, price: 450, pairsWith: 3},
+		{name: "Cola", category: product.ProductCategorySoft, price: 300, pairsWith: -1},
+	}},
+	{name: "Pizza Corner", category: stand.StandCategoryFood, location: "Food court", products: []catalogProduct{
+		{name: "Margherita", category: product.ProductCategoryFood, price: 1000, pairsWith: 3},
+		{name: "Pepperoni", category: product.ProductCategoryFood, price: 1200, pairsWith: 3},
+		{name: "Tiramisu", category: product.ProductCategoryFood, price: 500, pairsWith: -1},
+		{name: "Lemonade", category: product.ProductCategorySoft, price: 350, pairsWith: 2},
+	}},
+	{name: "Merch Tent", category: stand.StandCategoryMerchandise, location: "Entrance", products: []catalogProduct{
+		{name: "Festival T-shirt", category: product.ProductCategoryMerch, price: 2500, stock: 120, pairsWith: -1},
+		{name: "Cap", category: product.ProductCategoryMerch, price: 1800, stock: 60, pairsWith: -1},
+		{name: "Rain Poncho", category: product.ProductCategoryMerch, price: 800, stock: 40, pairsWith: -1},
+	}},
+}
+
+// hourWeights is the relative order activity per UTC hour, busiest in the evening
+var hourWeights = [24]float64{
+	0.6, 0.4, 0.2, 0.05, 0, 0, 0, 0, 0, 0.05, 0.1, 0.2,
+	0.4, 0.45, 0.4, 0.45, 0.55, 0.7, 0.9, 1, 1, 0.95, 0.85, 0.75,
+}
+
+// topUpAmounts are the amounts attendees load on their wallet, in cents
+var topUpAmounts = []int64{2000, 3000, 5000, 10000}
+
+// demoStand is a generated stand with its staff member and products
+type demoStand struct {
+	stand    stand.Stand
+	staffID  uuid.UUID
+	products []product.Product
+	pairs    []int
+}
+
+// attendee is a generated attendee with their wallet
+type attendee struct {
+	user     user.User
+	wallet   wallet.Wallet
+	platform string
+	joinedAt time.Time
+}
+
+// generator builds the dataset of a demo festival
+type generator struct {
+	rng        *rand.Rand
+	festivalID uuid.UUID
+	data       *Dataset
+}
+
+// Generate builds a demo festival's users, stands, products, wallets, orders and analytics
+// events between start and now. Attendees join during the first 60% of the period and
+// top up their wallet again when it runs short; orders follow the evening peaks and
+// some products are usually bought together. Stock of limited products runs down.
+func Generate(festivalID uuid.UUID, req SeedRequest, start, now time.Time, rng *rand.Rand) *Dataset {
+	req = req.withDefaults()
+	g := &generator{rng: rng, festivalID: festivalID, data: &Dataset{}}
+	tag := festivalID.String()[:8]
+
+	g.data.Users = append(g.data.Users, newUser(fmt.Sprintf("organizer+%s@example.com", tag), "Demo Organizer", user.UserRoleOrganizer, start))
+
+	stands := make([]*demoStand, len(catalog))
+	for i, cs := range catalog {
+		staff := newUser(fmt.Sprintf("staff-%d+%s@example.com", i+1, tag), cs.name+" Staff", user.UserRoleStaff, start)
+		g.data.Users = append(g.data.Users, staff)
+		stands[i] = g.newStand(cs, staff.ID, start)
+	}
+
+	span := now.Sub(start)
+	attendees := make([]*attendee, req.Attendees)
+	for i := range attendees {
+		joinedAt := start.Add(time.Duration(rng.Int63n(int64(span) * 6 / 10)))
+		a := &attendee{
+			user:     newUser(fmt.Sprintf("attendee-%03d+%s@example.com", i+1, tag), fmt.Sprintf("Demo Attendee %d", i+1), user.UserRoleUser, joinedAt),
+			platform: []string{"ios", "android"}[rng.Intn(2)],
+			joinedAt: joinedAt,
+		}
+		a.wallet = wallet.Wallet{
+			ID:         uuid.New(),
+			UserID:     &a.user.ID,
+			FestivalID: festivalID,
+			Status:     wallet.WalletStatusActive,
+			CreatedAt:  joinedAt,
+		}
+		attendees[i] = a
+
+		g.event(a, stats.EventTypeAppOpen, "app", "open", "", 0, nil, joinedAt.Add(-time.Minute))
+		g.topUp(a, topUpAmounts[rng.Intn(len(topUpAmounts))], joinedAt)
+		g.event(a, stats.EventTypeLineupView, "lineup", "view", "", 0, nil, orderTime(rng, joinedAt, now))
+	}
+
+	// Orders are replayed in time so wallet balances stay consistent
+	type slot struct {
+		attendee *attendee
+		at       time.Time
+	}
+	slots := make([]slot, req.Orders)
+	for i := range slots {
+		a := attendees[rng.Intn(len(attendees))]
+		slots[i] = slot{attendee: a, at: orderTime(rng, a.joinedAt, now)}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].at.Before(slots[j].at) })
+
+	for _, s := range slots {
+		g.order(s.attendee, stands[rng.Intn(len(stands))], s.at, now)
+	}
+
+	for _, a := range attendees {
+		g.data.Users = append(g.data.Users, a.user)
+		g.data.Wallets = append(g.data.Wallets, a.wallet)
+	}
+	for _, ds := range stands {
+		for _, p := range ds.products {
+			if p.Stock != nil && *p.Stock == 0 {
+				p.Status = product.ProductStatusOutOfStock
+			}
+			g.data.Products = append(g.data.Products, p)
+		}
+	}
+
+	return g.data
+}
+
+func newUser(email, name string, role user.UserRole, createdAt time.Time) user.User {
+	id := uuid.New()
+	return user.User{
+		ID:        id,
+		Email:     email,
+		Name:      name,
+		Role:      role,
+		Auth0ID:   "demo|" + id.String(),
+		Status:    user.UserStatusActive,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func (g *generator) newStand(cs catalogStand, staffID uuid.UUID, createdAt time.Time) *demoStand {
+	ds := &demoStand{
+		stand: stand.Stand{
+			ID:         uuid.New(),
+			FestivalID: g.festivalID,
+			Name:       cs.name,
+			Category:   cs.category,
+			Location:   cs.location,
+			Status:     stand.StandStatusActive,
+			CreatedAt:  createdAt,
+			UpdatedAt:  createdAt,
+		},
+		staffID: staffID,
+	}
+	g.data.Stands = append(g.data.Stands, ds.stand)
+
+	for i, cp := range cs.products {
+		p := product.Product{
+			ID:        uuid.New(),
+			StandID:   ds.stand.ID,
+			Name:      cp.name,
+			Price:     cp.price,
+			Category:  cp.category,
+			SortOrder: i,
+			Status:    product.ProductStatusActive,
+			Tags:      []string{},
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		if cp.stock > 0 {
+			stock := cp.stock
+			p.Stock = &stock
+		}
+		ds.products = append(ds.products, p)
+		ds.pairs = append(ds.pairs, cp.pairsWith)
+	}
+	return ds
+}
+
+func (g *generator) topUp(a *attendee, amount int64, at time.Time) {
+	g.data.Transactions = append(g.data.Transactions, wallet.Transaction{
+		ID:            uuid.New(),
+		WalletID:      a.wallet.ID,
+		Type:          wallet.TransactionTypeTopUp,
+		Amount:        amount,
+		BalanceBefore: a.wallet.Balance,
+		BalanceAfter:  a.wallet.Balance + amount,
+		Reference:     "demo",
+		Metadata:      wallet.TransactionMeta{Description: "Demo top-up", PaymentMethod: "card"},
+		Status:        wallet.TransactionStatusCompleted,
+		CreatedAt:     at,
+	})
+	a.wallet.Balance += amount
+	a.wallet.UpdatedAt = at
+	g.event(a, stats.EventTypeWalletTopUp, "wallet", "top_up", "", float64(amount), nil, at)
+}
+
+// order pays an order at a stand from the attendee's wallet, topping it up first when
+// it runs short. Nothing is ordered when the picked products are out of stock.
+func (g *generator) order(a *attendee, ds *demoStand, at, now time.Time) {
+	items := g.pickItems(ds)
+	if len(items) == 0 {
+		return
+	}
+	var total int64
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		total += item.TotalPrice
+		productIDs[i] = item.ProductID.String()
+	}
+
+	if a.wallet.Balance < total {
+		amount := topUpAmounts[g.rng.Intn(len(topUpAmounts))]
+		for a.wallet.Balance+amount < total {
+			amount += topUpAmounts[0]
+		}
+		g.topUp(a, amount, at)
+	}
+
+	orderID := uuid.New()
+	tx := wallet.Transaction{
+		ID:            uuid.New(),
+		WalletID:      a.wallet.ID,
+		Type:          wallet.TransactionTypePurchase,
+		Amount:        -total,
+		BalanceBefore: a.wallet.Balance,
+		BalanceAfter:  a.wallet.Balance - total,
+		Reference:     orderID.String(),
+		StandID:       &ds.stand.ID,
+		StaffID:       &ds.staffID,
+		Metadata:      wallet.TransactionMeta{Description: ds.stand.Name, ProductIDs: productIDs, PaymentMethod: order.PaymentMethodWallet},
+		Status:        wallet.TransactionStatusCompleted,
+		CreatedAt:     at,
+	}
+	g.data.Transactions = append(g.data.Transactions, tx)
+	a.wallet.Balance -= total
+	a.wallet.UpdatedAt = at
+
+	o := order.Order{
+		ID:            orderID,
+		FestivalID:    g.festivalID,
+		UserID:        a.user.ID,
+		WalletID:      a.wallet.ID,
+		StandID:       ds.stand.ID,
+		Items:         items,
+		TotalAmount:   total,
+		Status:        order.OrderStatusPaid,
+		PaymentMethod: order.PaymentMethodWallet,
+		TransactionID: &tx.ID,
+		StaffID:       &ds.staffID,
+		CreatedAt:     at,
+		UpdatedAt:     at,
+	}
+	if readyAt := at.Add(time.Duration(2+g.rng.Intn(7)) * time.Minute); readyAt.Before(now) {
+		o.ReadyAt = &readyAt
+		o.UpdatedAt = readyAt
+	}
+	g.data.Orders = append(g.data.Orders, o)
+
+	standData := map[string]interface{}{"standId": ds.stand.ID.String()}
+	g.event(a, stats.EventTypeStandVisit, "stand", "visit", ds.stand.Name, 0, standData, at.Add(-time.Minute))
+	g.event(a, stats.EventTypePurchase, "order", "purchase", ds.stand.Name, float64(total), map[string]interface{}{
+		"orderId": orderID.String(),
+		"standId": ds.stand.ID.String(),
+		"items":   len(items),
+	}, at)
+}
+
+// pickItems picks a product of the stand, often with the product usually bought with it
+func (g *generator) pickItems(ds *demoStand) order.OrderItems {
+	var items order.OrderItems
+	add := func(i int) {
+		p := &ds.products[i]
+		for _, item := range items {
+			if item.ProductID == p.ID {
+				return
+			}
+		}
+		quantity := 1
+		if p.Stock == nil {
+			quantity += g.rng.Intn(2)
+		} else if *p.Stock < quantity {
+			return
+		} else {
+			*p.Stock -= quantity
+		}
+		items = append(items, order.OrderItem{
+			ProductID:   p.ID,
+			ProductName: p.Name,
+			Quantity:    quantity,
+			UnitPrice:   p.Price,
+			TotalPrice:  int64(quantity) * p.Price,
+		})
+	}
+
+	first := g.rng.Intn(len(ds.products))
+	add(first)
+	if pair := ds.pairs[first]; pair >= 0 && g.rng.Float64() < 0.6 {
+		add(pair)
+	}
+	if g.rng.Float64() < 0.15 {
+		add(g.rng.Intn(len(ds.products)))
+	}
+	return items
+}
+
+func (g *generator) event(a *attendee, eventType stats.EventType, category, action, label string, value float64, data map[string]interface{}, at time.Time) {
+	userID := a.user.ID
+	g.data.Events = append(g.data.Events, &stats.AnalyticsEvent{
+		ID:         uuid.New(),
+		FestivalID: g.festivalID,
+		UserID:     &userID,
+		SessionID:  fmt.Sprintf("demo-%s-%s", a.user.ID.String()[:8], at.Format("20060102")),
+		Type:       eventType,
+		Category:   category,
+		Action:     action,
+		Label:      label,
+		Value:      value,
+		Data:       data,
+		DeviceType: "mobile",
+		Platform:   a.platform,
+		AppVersion: "demo",
+		Timestamp:  at,
+	})
+}
+
+// orderTime picks a time between from and to following hourWeights
+func orderTime(rng *rand.Rand, from, to time.Time) time.Time {
+	span := int64(to.Sub(from))
+	if span <= 0 {
+		return to
+	}
+	for i := 0; i < 100; i++ {
+		at := from.Add(time.Duration(rng.Int63n(span)))
+		if rng.Float64() < hourWeights[at.UTC().Hour()] {
+			return at
+		}
+	}
+	return from.Add(time.Duration(rng.Int63n(span)))
+}
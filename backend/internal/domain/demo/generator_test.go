@@ -0,0 +1,161 @@
+package demo
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stats"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	festivalID := uuid.New()
+	now := time.Date(2026, 7, 18, 21, 30, 0, 0, time.UTC)
+	start := time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)
+
+	data := Generate(festivalID, SeedRequest{Attendees: 20, Orders: 300}, start, now, rand.New(rand.NewSource(42)))
+
+	// An organizer, a staff member per stand and the attendees
+	assert.Len(t, data.Users, 1+len(catalog)+20)
+	assert.Len(t, data.Stands, len(catalog))
+	require.Len(t, data.Wallets, 20)
+	assert.NotEmpty(t, data.Orders)
+	assert.LessOrEqual(t, len(data.Orders), 300)
+
+	users := make(map[uuid.UUID]user.User)
+	emails := make(map[string]bool)
+	for _, u := range data.Users {
+		users[u.ID] = u
+		assert.False(t, emails[u.Email], "duplicate email %s", u.Email)
+		emails[u.Email] = true
+	}
+
+	// Replaying the transactions of each wallet gives its balance
+	balances := make(map[uuid.UUID]int64)
+	transactions := make(map[uuid.UUID]wallet.Transaction)
+	for _, tx := range data.Transactions {
+		assert.Equal(t, balances[tx.WalletID], tx.BalanceBefore)
+		assert.Equal(t, tx.BalanceBefore+tx.Amount, tx.BalanceAfter)
+		assert.GreaterOrEqual(t, tx.BalanceAfter, int64(0))
+		balances[tx.WalletID] = tx.BalanceAfter
+		transactions[tx.ID] = tx
+	}
+	for _, w := range data.Wallets {
+		assert.Equal(t, festivalID, w.FestivalID)
+		assert.Equal(t, balances[w.ID], w.Balance)
+		require.NotNil(t, w.UserID)
+		assert.Equal(t, user.UserRoleUser, users[*w.UserID].Role)
+	}
+
+	products := make(map[uuid.UUID]product.Product)
+	for _, p := range data.Products {
+		products[p.ID] = p
+	}
+	for _, o := range data.Orders {
+		assert.Equal(t, order.OrderStatusPaid, o.Status)
+		assert.False(t, o.CreatedAt.Before(start))
+		assert.False(t, o.CreatedAt.After(now))
+		if o.ReadyAt != nil {
+			assert.True(t, o.ReadyAt.Before(now))
+		}
+
+		var total int64
+		for _, item := range o.Items {
+			p, ok := products[item.ProductID]
+			require.True(t, ok)
+			assert.Equal(t, o.StandID, p.StandID)
+			assert.Equal(t, int64(item.Quantity)*p.Price, item.TotalPrice)
+			total += item.TotalPrice
+		}
+		assert.Equal(t, total, o.TotalAmount)
+
+		require.NotNil(t, o.TransactionID)
+		tx := transactions[*o.TransactionID]
+		assert.Equal(t, wallet.TransactionTypePurchase, tx.Type)
+		assert.Equal(t, -o.TotalAmount, tx.Amount)
+		assert.Equal(t, o.WalletID, tx.WalletID)
+	}
+
+	var purchases int
+	for _, e := range data.Events {
+		assert.Equal(t, festivalID, e.FestivalID)
+		if e.Type == stats.EventTypePurchase {
+			purchases++
+		}
+	}
+	assert.Equal(t, len(data.Orders), purchases)
+}
+
+func TestGenerate_LimitedStock(t *testing.T) {
+	now := time.Date(2026, 7, 18, 21, 30, 0, 0, time.UTC)
+	start := now.AddDate(0, 0, -3)
+
+	data := Generate(uuid.New(), SeedRequest{Attendees: 100, Orders: 5000}, start, now, rand.New(rand.NewSource(7)))
+
+	sold := make(map[uuid.UUID]int)
+	for _, o := range data.Orders {
+		for _, item := range o.Items {
+			sold[item.ProductID] += item.Quantity
+		}
+	}
+	var outOfStock int
+	for _, p := range data.Products {
+		if p.Stock == nil {
+			assert.Equal(t, product.ProductStatusActive, p.Status)
+			continue
+		}
+		assert.GreaterOrEqual(t, *p.Stock, 0)
+		if *p.Stock == 0 {
+			assert.Equal(t, product.ProductStatusOutOfStock, p.Status)
+			outOfStock++
+		}
+	}
+	// Ponchos sell out long before 5000 orders
+	assert.Positive(t, outOfStock)
+	for _, cs := range catalog {
+		for _, cp := range cs.products {
+			if cp.stock == 0 {
+				continue
+			}
+			for _, p := range data.Products {
+				if p.Name == cp.name {
+					assert.Equal(t, cp.stock, *p.Stock+sold[p.ID])
+				}
+			}
+		}
+	}
+}
+
+func TestGenerate_SameSeed(t *testing.T) {
+	now := time.Date(2026, 7, 18, 21, 30, 0, 0, time.UTC)
+	start := now.AddDate(0, 0, -2)
+	req := SeedRequest{Attendees: 10, Orders: 50}
+
+	a := Generate(uuid.New(), req, start, now, rand.New(rand.NewSource(99)))
+	b := Generate(uuid.New(), req, start, now, rand.New(rand.NewSource(99)))
+
+	require.Equal(t, len(a.Orders), len(b.Orders))
+	for i := range a.Orders {
+		assert.Equal(t, a.Orders[i].TotalAmount, b.Orders[i].TotalAmount)
+		assert.Equal(t, a.Orders[i].CreatedAt, b.Orders[i].CreatedAt)
+	}
+}
+
+func TestSeedRequestDefaults(t *testing.T) {
+	req := SeedRequest{}.withDefaults()
+	assert.Equal(t, DefaultName, req.Name)
+	assert.Equal(t, DefaultAttendees, req.Attendees)
+	assert.Equal(t, DefaultOrders, req.Orders)
+	assert.Equal(t, DefaultDays, req.Days)
+
+	req = SeedRequest{Name: "Sales demo", Orders: 10}.withDefaults()
+	assert.Equal(t, "Sales demo", req.Name)
+	assert.Equal(t, 10, req.Orders)
+}
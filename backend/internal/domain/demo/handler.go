@@ -0,0 +1,59 @@
+package demo
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers demo provisioning, which should be restricted to admins and
+// never registered in production
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/demo/festivals", h.Seed)
+}
+
+// Seed provisions a demo festival
+// @Summary Seed demo festival
+// @Description Provision an active festival with users, funded wallets, stands, products, order history and analytics events for sales demos and frontend development. Not available in production.
+// @Tags demo
+// @Accept json
+// @Produce json
+// @Param request body SeedRequest false "Size of the demo festival"
+// @Success 201 {object} response.Response{data=SeedResult} "Demo festival"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/demo/festivals [post]
+func (h *Handler) Seed(c *gin.Context) {
+	var req SeedRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	var createdBy *uuid.UUID
+	if userID := c.GetString("user_id"); userID != "" {
+		if id, err := uuid.Parse(userID); err == nil {
+			createdBy = &id
+		}
+	}
+
+	result, err := h.service.Seed(c.Request.Context(), req, createdBy)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, result)
+}
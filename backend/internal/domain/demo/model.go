@@ -0,0 +1,74 @@
+package demo
+
+import (
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/festival"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/domain/stats"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+)
+
+// Seeding defaults and limits
+const (
+	DefaultName      = "Demo Festival"
+	DefaultAttendees = 50
+	DefaultOrders    = 600
+	DefaultDays      = 3
+)
+
+// SeedRequest configures the demo festival to provision
+type SeedRequest struct {
+	Name      string `json:"name" binding:"omitempty,max=100"`
+	Attendees int    `json:"attendees" binding:"omitempty,min=1,max=500"`
+	Orders    int    `json:"orders" binding:"omitempty,min=1,max=5000"`
+	Days      int    `json:"days" binding:"omitempty,min=1,max=14"` // Days of history before now
+	Seed      int64  `json:"seed"`                                  // Same seed, same amounts and timing; random when 0
+}
+
+// withDefaults fills in the omitted fields
+func (r SeedRequest) withDefaults() SeedRequest {
+	if r.Name == "" {
+		r.Name = DefaultName
+	}
+	if r.Attendees == 0 {
+		r.Attendees = DefaultAttendees
+	}
+	if r.Orders == 0 {
+		r.Orders = DefaultOrders
+	}
+	if r.Days == 0 {
+		r.Days = DefaultDays
+	}
+	return r
+}
+
+// Dataset is everything generated for a demo festival, inserted in one transaction
+type Dataset struct {
+	Users        []user.User
+	Wallets      []wallet.Wallet
+	Transactions []wallet.Transaction
+	Stands       []stand.Stand
+	Products     []product.Product
+	Orders       []order.Order
+	Events       []*stats.AnalyticsEvent
+}
+
+// SeedResult describes the provisioned demo festival
+type SeedResult struct {
+	Festival     *festival.Festival `json:"festival"`
+	OrganizerID  uuid.UUID          `json:"organizerId"`
+	AttendeeIDs  []uuid.UUID        `json:"attendeeIds"`
+	Users        int                `json:"users"`
+	Wallets      int                `json:"wallets"`
+	Stands       int                `json:"stands"`
+	Products     int                `json:"products"`
+	Orders       int                `json:"orders"`
+	Transactions int                `json:"transactions"`
+	Events       int                `json:"events"`
+	Revenue      int64              `json:"revenue"`      // Paid orders, in cents
+	TotalBalance int64              `json:"totalBalance"` // Left in the wallets, in cents
+	Seed         int64              `json:"seed"`
+}
@@ -0,0 +1,63 @@
+package demo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/stats"
+	"gorm.io/gorm"
+)
+
+// insertBatchSize bounds the rows inserted per statement
+const insertBatchSize = 500
+
+type Repository interface {
+	Insert(ctx context.Context, data *Dataset) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Insert stores a generated dataset in a single transaction
+func (r *repository) Insert(ctx context.Context, data *Dataset) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createInBatches(tx, "users", &data.Users, len(data.Users)); err != nil {
+			return err
+		}
+		if err := createInBatches(tx, "stands", &data.Stands, len(data.Stands)); err != nil {
+			return err
+		}
+		if err := createInBatches(tx, "products", &data.Products, len(data.Products)); err != nil {
+			return err
+		}
+		if err := createInBatches(tx, "wallets", &data.Wallets, len(data.Wallets)); err != nil {
+			return err
+		}
+		if err := createInBatches(tx, "transactions", &data.Transactions, len(data.Transactions)); err != nil {
+			return err
+		}
+		if err := createInBatches(tx, "orders", &data.Orders, len(data.Orders)); err != nil {
+			return err
+		}
+		if err := stats.NewAnalyticsRepository(tx).CreateEventsBatch(ctx, data.Events); err != nil {
+			return fmt.Errorf("failed to create demo analytics events: %w", err)
+		}
+		return nil
+	})
+}
+
+// createInBatches inserts the rows of a slice, skipping empty slices GORM refuses
+func createInBatches(tx *gorm.DB, name string, rows interface{}, count int) error {
+	if count == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(rows, insertBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to create demo %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package demo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/festival"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/rs/zerolog/log"
+)
+
+// FestivalProvisioner creates festivals with their tenant schema; satisfied by festival.Service
+type FestivalProvisioner interface {
+	Create(ctx context.Context, req festival.CreateFestivalRequest, createdBy *uuid.UUID) (*festival.Festival, error)
+	Activate(ctx context.Context, id uuid.UUID) (*festival.Festival, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Service provisions demo festivals filled with realistic data
+type Service struct {
+	repo      Repository
+	festivals FestivalProvisioner
+	now       func() time.Time
+}
+
+// NewService creates a new demo service
+func NewService(repo Repository, festivals FestivalProvisioner) *Service {
+	return &Service{
+		repo:      repo,
+		festivals: festivals,
+		now:       time.Now,
+	}
+}
+
+// Seed provisions an active demo festival with users, stands, products, funded wallets,
+// order history and analytics events. The festival is deleted again if seeding fails.
+func (s *Service) Seed(ctx context.Context, req SeedRequest, createdBy *uuid.UUID) (*SeedResult, error) {
+	req = req.withDefaults()
+	if req.Seed == 0 {
+		req.Seed = s.now().UnixNano()
+	}
+
+	now := s.now().UTC()
+	start := now.Truncate(24*time.Hour).AddDate(0, 0, -req.Days)
+	f, err := s.festivals.Create(ctx, festival.CreateFestivalRequest{
+		Name:        req.Name,
+		Description: "Demo festival with generated data",
+		StartDate:   start,
+		EndDate:     now.Truncate(24*time.Hour).AddDate(0, 0, 2),
+		Location:    "Demo grounds",
+		Sandbox:     true,
+	}, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	data := Generate(f.ID, req, start, now, rand.New(rand.NewSource(req.Seed)))
+	if err := s.repo.Insert(ctx, data); err != nil {
+		s.rollback(ctx, f.ID)
+		return nil, err
+	}
+
+	activated, err := s.festivals.Activate(ctx, f.ID)
+	if err != nil {
+		s.rollback(ctx, f.ID)
+		return nil, fmt.Errorf("failed to activate demo festival: %w", err)
+	}
+
+	return summarize(activated, data, req.Seed), nil
+}
+
+// rollback deletes a demo festival that could not be seeded
+func (s *Service) rollback(ctx context.Context, festivalID uuid.UUID) {
+	if err := s.festivals.Delete(ctx, festivalID); err != nil {
+		log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to delete demo festival after failed seeding")
+	}
+}
+
+func summarize(f *festival.Festival, data *Dataset, seed int64) *SeedResult {
+	result := &SeedResult{
+		Festival:     f,
+		AttendeeIDs:  []uuid.UUID{},
+		Users:        len(data.Users),
+		Wallets:      len(data.Wallets),
+		Stands:       len(data.Stands),
+		Products:     len(data.Products),
+		Orders:       len(data.Orders),
+		Transactions: len(data.Transactions),
+		Events:       len(data.Events),
+		Seed:         seed,
+	}
+	for _, u := range data.Users {
+		switch u.Role {
+		case user.UserRoleOrganizer:
+			result.OrganizerID = u.ID
+		case user.UserRoleUser:
+			result.AttendeeIDs = append(result.AttendeeIDs, u.ID)
+		}
+	}
+	for _, o := range data.Orders {
+		result.Revenue += o.TotalAmount
+	}
+	for _, w := range data.Wallets {
+		result.TotalBalance += w.Balance
+	}
+	return result
+}
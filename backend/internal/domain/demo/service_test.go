@@ -0,0 +1,96 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/festival"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	inserted *Dataset
+	err      error
+}
+
+func (r *fakeRepository) Insert(ctx context.Context, data *Dataset) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.inserted = data
+	return nil
+}
+
+type fakeFestivals struct {
+	festivals map[uuid.UUID]*festival.Festival
+}
+
+func (f *fakeFestivals) Create(ctx context.Context, req festival.CreateFestivalRequest, createdBy *uuid.UUID) (*festival.Festival, error) {
+	created := &festival.Festival{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Status:    festival.FestivalStatusDraft,
+		CreatedBy: createdBy,
+	}
+	f.festivals[created.ID] = created
+	return created, nil
+}
+
+func (f *fakeFestivals) Activate(ctx context.Context, id uuid.UUID) (*festival.Festival, error) {
+	f.festivals[id].Status = festival.FestivalStatusActive
+	return f.festivals[id], nil
+}
+
+func (f *fakeFestivals) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.festivals, id)
+	return nil
+}
+
+func TestSeed(t *testing.T) {
+	repo := &fakeRepository{}
+	festivals := &fakeFestivals{festivals: make(map[uuid.UUID]*festival.Festival)}
+	service := NewService(repo, festivals)
+	now := time.Date(2026, 7, 18, 21, 30, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	adminID := uuid.New()
+
+	result, err := service.Seed(context.Background(), SeedRequest{Attendees: 5, Orders: 40, Days: 2, Seed: 3}, &adminID)
+	require.NoError(t, err)
+	require.NotNil(t, repo.inserted)
+
+	assert.Equal(t, DefaultName, result.Festival.Name)
+	assert.Equal(t, festival.FestivalStatusActive, result.Festival.Status)
+	assert.Equal(t, &adminID, result.Festival.CreatedBy)
+	assert.Equal(t, time.Date(2026, 7, 16, 0, 0, 0, 0, time.UTC), result.Festival.StartDate)
+	assert.True(t, result.Festival.EndDate.After(now))
+
+	assert.Len(t, result.AttendeeIDs, 5)
+	assert.NotEqual(t, uuid.Nil, result.OrganizerID)
+	assert.Equal(t, len(repo.inserted.Orders), result.Orders)
+	assert.Equal(t, int64(3), result.Seed)
+	assert.Positive(t, result.Revenue)
+
+	var topUps int64
+	for _, tx := range repo.inserted.Transactions {
+		if tx.Amount > 0 {
+			topUps += tx.Amount
+		}
+	}
+	assert.Equal(t, topUps-result.Revenue, result.TotalBalance)
+}
+
+func TestSeed_DeletesFestivalOnFailure(t *testing.T) {
+	repo := &fakeRepository{err: errors.New("insert failed")}
+	festivals := &fakeFestivals{festivals: make(map[uuid.UUID]*festival.Festival)}
+	service := NewService(repo, festivals)
+
+	_, err := service.Seed(context.Background(), SeedRequest{Attendees: 2, Orders: 5}, nil)
+	assert.Error(t, err)
+	assert.Empty(t, festivals.festivals)
+}
@@ -0,0 +1,127 @@
+package diagnostics
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/database"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// SlowQuerySource returns the slow statements recorded on the database, satisfied by
+// database.SlowQueryPlugin
+type SlowQuerySource interface {
+	SlowQueries(domain string) []database.SlowQueryStat
+	ResetSlowQueries()
+}
+
+// RedisMemorySource returns the Redis memory usage per key prefix, satisfied by
+// cache.MemoryGovernor
+type RedisMemorySource interface {
+	Report() *cache.MemoryReport
+	Sweep(ctx context.Context) (*cache.MemoryReport, error)
+}
+
+type Handler struct {
+	slowQueries SlowQuerySource
+	redisMemory RedisMemorySource
+}
+
+func NewHandler(slowQueries SlowQuerySource, redisMemory RedisMemorySource) *Handler {
+	return &Handler{slowQueries: slowQueries, redisMemory: redisMemory}
+}
+
+// RegisterRoutes registers the admin diagnostics routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	diagnostics := r.Group("/diagnostics")
+	{
+		diagnostics.GET("/slow-queries", h.ListSlowQueries)
+		diagnostics.DELETE("/slow-queries", h.ResetSlowQueries)
+		diagnostics.GET("/redis-memory", h.GetRedisMemory)
+		diagnostics.POST("/redis-memory/sweep", h.SweepRedisMemory)
+	}
+}
+
+// ListSlowQueries returns the statements that ran above the slow query threshold
+// @Summary List slow queries
+// @Description List the statements of this instance that ran above the slow query threshold since it started or since the last reset, the most costly in total first. Statements are shown with their placeholders; a sample of the worst runs of each SELECT comes with its EXPLAIN ANALYZE plan.
+// @Tags diagnostics
+// @Produce json
+// @Param domain query string false "Only the statements issued by a domain, e.g. wallet"
+// @Success 200 {object} response.Response{data=[]database.SlowQueryStat} "Slow queries"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 503 {object} response.ErrorResponse "Slow query detection disabled"
+// @Security BearerAuth
+// @Router /admin/diagnostics/slow-queries [get]
+func (h *Handler) ListSlowQueries(c *gin.Context) {
+	if h.slowQueries == nil {
+		response.ServiceUnavailable(c, "slow query detection disabled")
+		return
+	}
+
+	response.OK(c, h.slowQueries.SlowQueries(c.Query("domain")))
+}
+
+// ResetSlowQueries clears the recorded slow queries
+// @Summary Reset slow queries
+// @Description Clear the slow queries recorded by this instance, e.g. after deploying an index. The metrics are not reset.
+// @Tags diagnostics
+// @Success 204 "Slow queries cleared"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 503 {object} response.ErrorResponse "Slow query detection disabled"
+// @Security BearerAuth
+// @Router /admin/diagnostics/slow-queries [delete]
+func (h *Handler) ResetSlowQueries(c *gin.Context) {
+	if h.slowQueries == nil {
+		response.ServiceUnavailable(c, "slow query detection disabled")
+		return
+	}
+
+	h.slowQueries.ResetSlowQueries()
+	response.NoContent(c)
+}
+
+// GetRedisMemory returns the Redis memory usage per key prefix
+// @Summary Get Redis memory usage
+// @Description Get the memory used by the Redis instance and by the keys under each governed prefix at the last sweep, with their budgets. Writers of a prefix over its budget sample or aggregate what they store until it falls under 90% of the budget.
+// @Tags diagnostics
+// @Produce json
+// @Success 200 {object} response.Response{data=cache.MemoryReport} "Redis memory usage"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "No sweep yet"
+// @Security BearerAuth
+// @Router /admin/diagnostics/redis-memory [get]
+func (h *Handler) GetRedisMemory(c *gin.Context) {
+	report := h.redisMemory.Report()
+	if report == nil {
+		response.NotFound(c, "Redis memory not measured yet")
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// SweepRedisMemory measures the Redis memory usage per key prefix now
+// @Summary Sweep Redis memory
+// @Description Measure the memory of the keys under each governed prefix now, giving the maximum TTL of the prefix to the keys without expiry or expiring later
+// @Tags diagnostics
+// @Produce json
+// @Success 200 {object} response.Response{data=cache.MemoryReport} "Redis memory usage"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 500 {object} response.ErrorResponse "Sweep failed"
+// @Security BearerAuth
+// @Router /admin/diagnostics/redis-memory/sweep [post]
+func (h *Handler) SweepRedisMemory(c *gin.Context) {
+	report, err := h.redisMemory.Sweep(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, report)
+}
@@ -0,0 +1,279 @@
+package display
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// TokenIDKey is the context key of the display token ID set by Authenticate
+const TokenIDKey = "display_token_id"
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped display token management routes of the
+// organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	tokens := r.Group("/display-tokens")
+	{
+		tokens.GET("", h.ListTokens)
+		tokens.POST("", h.CreateToken)
+		tokens.DELETE("/:tokenId", h.RevokeToken)
+	}
+}
+
+// RegisterBoardRoutes registers the read-only routes of the menu boards, authenticated
+// with their display token. menuUpdates serves the WebSocket of the menu updates.
+func (h *Handler) RegisterBoardRoutes(r *gin.RouterGroup, menuUpdates gin.HandlerFunc) {
+	board := r.Group("")
+	board.Use(h.Authenticate)
+	{
+		board.GET("/menu", h.Menu)
+		board.GET("/wait-time", h.WaitTime)
+		board.GET("/now-serving", h.NowServing)
+		board.GET("/ws", menuUpdates)
+	}
+}
+
+// ListTokens lists the display tokens of the festival
+// @Summary List display tokens
+// @Description List the display tokens of the menu boards of the festival
+// @Tags display
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the tokens of this stand" format(uuid)
+// @Param includeRevoked query bool false "Include the revoked tokens"
+// @Success 200 {object} response.Response{data=[]Token} "Display tokens"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/display-tokens [get]
+func (h *Handler) ListTokens(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return
+	}
+	includeRevoked, _ := strconv.ParseBool(c.Query("includeRevoked"))
+
+	tokens, err := h.service.ListTokens(c.Request.Context(), festivalID, ListTokensQuery{
+		StandID:        standID,
+		IncludeRevoked: includeRevoked,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, tokens)
+}
+
+// CreateToken creates a display token for a stand
+// @Summary Create display token
+// @Description Create a read-only token for the menu board of a stand. It does not expire; the token is only returned once.
+// @Tags display
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateTokenRequest true "Display token"
+// @Success 201 {object} response.Response{data=CreatedToken} "Display token"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/display-tokens [post]
+func (h *Handler) CreateToken(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	token, err := h.service.CreateToken(c.Request.Context(), festivalID, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, token)
+}
+
+// RevokeToken revokes a display token
+// @Summary Revoke display token
+// @Description Revoke a display token at once. Its next request fails with DISPLAY_TOKEN_REVOKED and its menu update connections are closed.
+// @Tags display
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param tokenId path string true "Display token ID" format(uuid)
+// @Success 200 {object} response.Response{data=Token} "Revoked token"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Display token not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/display-tokens/{tokenId} [delete]
+func (h *Handler) RevokeToken(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	tokenID, err := uuid.Parse(c.Param("tokenId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid display token ID", nil)
+		return
+	}
+
+	token, err := h.service.RevokeToken(c.Request.Context(), festivalID, tokenID, currentUser(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, token)
+}
+
+// Menu returns the menu of the stand of the board
+// @Summary Get menu board menu
+// @Description Get the products of the stand at the prices in effect, sold out products included
+// @Tags display
+// @Produce json
+// @Success 200 {object} response.Response{data=Menu} "Menu"
+// @Failure 401 {object} response.ErrorResponse "Invalid or revoked display token"
+// @Security DisplayToken
+// @Router /display/menu [get]
+func (h *Handler) Menu(c *gin.Context) {
+	menu, err := h.service.Menu(c.Request.Context(), CurrentToken(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, menu)
+}
+
+// WaitTime returns the estimated wait at the stand of the board
+// @Summary Get menu board wait time
+// @Description Get the estimated wait at the stand, zero without recent orders
+// @Tags display
+// @Produce json
+// @Success 200 {object} response.Response{data=WaitTime} "Wait time"
+// @Failure 401 {object} response.ErrorResponse "Invalid or revoked display token"
+// @Security DisplayToken
+// @Router /display/wait-time [get]
+func (h *Handler) WaitTime(c *gin.Context) {
+	wait, err := h.service.WaitTime(c.Request.Context(), CurrentToken(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, wait)
+}
+
+// NowServing returns the order numbers ready for pickup at the stand of the board
+// @Summary Get menu board now-serving numbers
+// @Description Get the numbers of the pickup orders marked ready in the last 15 minutes, latest first
+// @Tags display
+// @Produce json
+// @Success 200 {object} response.Response{data=NowServing} "Now serving"
+// @Failure 401 {object} response.ErrorResponse "Invalid or revoked display token"
+// @Security DisplayToken
+// @Router /display/now-serving [get]
+func (h *Handler) NowServing(c *gin.Context) {
+	serving, err := h.service.NowServing(c.Request.Context(), CurrentToken(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, serving)
+}
+
+// Authenticate resolves the display token of a menu board, sent as a bearer token. The
+// WebSocket may pass it as the token query parameter instead, browsers cannot set
+// headers on it. It sets the festival and stand of the token in the context.
+func (h *Handler) Authenticate(c *gin.Context) {
+	secret := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if secret == "" && c.IsWebsocket() {
+		secret = c.Query("token")
+	}
+
+	token, err := h.service.Authenticate(c.Request.Context(), secret)
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set("display_token", token)
+	c.Set(TokenIDKey, token.ID.String())
+	c.Set("festival_id", token.FestivalID.String())
+	c.Set("stand_id", token.StandID.String())
+	c.Next()
+}
+
+// CurrentToken returns the display token set by Authenticate
+func CurrentToken(c *gin.Context) *Token {
+	return c.MustGet("display_token").(*Token)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid "+name, nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidToken):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, ErrTokenRevoked):
+		response.SendError(c, response.NewStandardError(http.StatusUnauthorized, "DISPLAY_TOKEN_REVOKED", err.Error(), nil))
+	case errors.Is(err, ErrTokenNotFound):
+		response.NotFound(c, "Display token not found")
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
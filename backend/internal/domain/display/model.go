@@ -0,0 +1,132 @@
+package display
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+)
+
+// Display token errors
+var (
+	ErrTokenNotFound = errors.New("display token not found")
+	ErrStandNotFound = errors.New("stand not found")
+	ErrInvalidToken  = errors.New("invalid display token")
+	ErrTokenRevoked  = errors.New("display token was revoked")
+)
+
+const (
+	// TokenPrefix starts every display token, so that a leaked token is recognisable
+	TokenPrefix = "disp_"
+
+	// NowServingWindow is how long a ready order stays on the now-serving list
+	NowServingWindow = 15 * time.Minute
+
+	// MaxNowServing is the most order numbers shown at once
+	MaxNowServing = 20
+
+	// touchInterval limits how often LastUsedAt is written for a busy board
+	touchInterval = time.Minute
+)
+
+// Token lets a digital menu board read the menu, wait time and now-serving numbers of
+// one stand without signing in. It does not expire, boards run for the whole festival,
+// but it can be revoked at once.
+type Token struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID     uuid.UUID  `json:"standId" gorm:"type:uuid;not null;index"`
+	Name        string     `json:"name" gorm:"not null"`
+	TokenHash   string     `json:"-" gorm:"not null;uniqueIndex"`
+	TokenPrefix string     `json:"tokenPrefix" gorm:"not null"` // Start of the token, to tell boards apart
+	CreatedBy   *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy   *uuid.UUID `json:"revokedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Token) TableName() string {
+	return "display_tokens"
+}
+
+// CreatedToken is returned once when a token is created, with the secret to set up on
+// the board
+type CreatedToken struct {
+	Token
+	DisplayToken string `json:"displayToken"`
+}
+
+// StandInfo is what a board shows of its stand
+type StandInfo struct {
+	ID         uuid.UUID `json:"id"`
+	FestivalID uuid.UUID `json:"festivalId"`
+	Name       string    `json:"name"`
+}
+
+// MenuItem is a product as shown on a board, at the price of the active price list
+type MenuItem struct {
+	ID          uuid.UUID               `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Category    product.ProductCategory `json:"category"`
+	Price       int64                   `json:"price"` // In cents
+	ImageURL    string                  `json:"imageUrl,omitempty"`
+	SoldOut     bool                    `json:"soldOut"`
+	Allergens   []product.Allergen      `json:"allergens,omitempty"`
+	DietaryTags []product.DietaryTag    `json:"dietaryTags,omitempty"`
+}
+
+// Menu is the menu of the stand of a board
+type Menu struct {
+	Stand       StandInfo  `json:"stand"`
+	Items       []MenuItem `json:"items"`
+	PriceListID *uuid.UUID `json:"priceListId,omitempty"` // Price list in effect, e.g. a happy hour
+}
+
+// WaitTime is the estimated wait at the stand of a board
+type WaitTime struct {
+	StandID          uuid.UUID  `json:"standId"`
+	EstimatedMinutes int        `json:"estimatedMinutes"`
+	Level            string     `json:"level,omitempty"`     // Empty without recent orders
+	UpdatedAt        *time.Time `json:"updatedAt,omitempty"` // Nil without recent orders
+}
+
+// ReadyOrder is an order marked ready for pickup
+type ReadyOrder struct {
+	ID      uuid.UUID
+	ReadyAt time.Time
+}
+
+// ServedOrder is an order ready for pickup
+type ServedOrder struct {
+	Number  string    `json:"number"` // Number printed on the ticket, e.g. #3F2A9C
+	ReadyAt time.Time `json:"readyAt"`
+}
+
+// NowServing lists the orders of a stand ready for pickup, latest first
+type NowServing struct {
+	StandID uuid.UUID     `json:"standId"`
+	Orders  []ServedOrder `json:"orders"`
+}
+
+// Revocation is sent on the menu board channel before the connections of a revoked
+// token are closed
+type Revocation struct {
+	TokenID uuid.UUID `json:"tokenId"`
+	StandID uuid.UUID `json:"standId"`
+}
+
+// CreateTokenRequest is the request to create a display token for a stand
+type CreateTokenRequest struct {
+	StandID uuid.UUID `json:"standId" binding:"required"`
+	Name    string    `json:"name" binding:"required,max=100"` // e.g. "Main bar, left screen"
+}
+
+// ListTokensQuery filters the display tokens of a festival
+type ListTokensQuery struct {
+	StandID        *uuid.UUID
+	IncludeRevoked bool
+}
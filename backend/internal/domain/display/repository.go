@@ -0,0 +1,141 @@
+package display
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateToken(ctx context.Context, token *Token) error
+	GetToken(ctx context.Context, festivalID, id uuid.UUID) (*Token, error)
+	GetTokenByHash(ctx context.Context, tokenHash string) (*Token, error)
+	ListTokens(ctx context.Context, festivalID uuid.UUID, query ListTokensQuery) ([]Token, error)
+	UpdateToken(ctx context.Context, token *Token) error
+	TouchToken(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error)
+	// ListMenuProducts lists the active and sold out products of a stand in menu order
+	ListMenuProducts(ctx context.Context, standID uuid.UUID) ([]product.Product, error)
+	// ListReadyOrders lists the paid pickup orders of a stand marked ready since, latest
+	// first
+	ListReadyOrders(ctx context.Context, standID uuid.UUID, since time.Time, limit int) ([]ReadyOrder, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateToken(ctx context.Context, token *Token) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create display token: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetToken(ctx context.Context, festivalID, id uuid.UUID) (*Token, error) {
+	var token Token
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get display token: %w", err)
+	}
+	return &token, nil
+}
+
+func (r *repository) GetTokenByHash(ctx context.Context, tokenHash string) (*Token, error) {
+	var token Token
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get display token: %w", err)
+	}
+	return &token, nil
+}
+
+func (r *repository) ListTokens(ctx context.Context, festivalID uuid.UUID, query ListTokensQuery) ([]Token, error) {
+	var tokens []Token
+	db := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if query.StandID != nil {
+		db = db.Where("stand_id = ?", *query.StandID)
+	}
+	if !query.IncludeRevoked {
+		db = db.Where("revoked_at IS NULL")
+	}
+	if err := db.Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to list display tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *repository) UpdateToken(ctx context.Context, token *Token) error {
+	if err := r.db.WithContext(ctx).Save(token).Error; err != nil {
+		return fmt.Errorf("failed to update display token: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) TouchToken(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Token{}).Where("id = ?", id).Update("last_used_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to touch display token: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	var infos []StandInfo
+	err := r.db.WithContext(ctx).
+		Table("public.stands").
+		Select("id, festival_id, name").
+		Where("id = ?", standID).
+		Limit(1).
+		Scan(&infos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	return &infos[0], nil
+}
+
+func (r *repository) ListMenuProducts(ctx context.Context, standID uuid.UUID) ([]product.Product, error) {
+	var products []product.Product
+	err := r.db.WithContext(ctx).
+		Where("stand_id = ? AND status IN ?", standID, []product.ProductStatus{product.ProductStatusActive, product.ProductStatusOutOfStock}).
+		Order("sort_order ASC, name ASC").
+		Find(&products).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list menu products: %w", err)
+	}
+	return products, nil
+}
+
+func (r *repository) ListReadyOrders(ctx context.Context, standID uuid.UUID, since time.Time, limit int) ([]ReadyOrder, error) {
+	var orders []ReadyOrder
+	err := r.db.WithContext(ctx).
+		Table("public.orders").
+		Select("id, ready_at").
+		Where("stand_id = ? AND status = 'PAID' AND delivery_status IS NULL AND ready_at >= ?", standID, since).
+		Order("ready_at DESC").
+		Limit(limit).
+		Scan(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ready orders: %w", err)
+	}
+	return orders, nil
+}
@@ -0,0 +1,76 @@
+package display
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateToken(ctx context.Context, token *Token) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetToken(ctx context.Context, festivalID, id uuid.UUID) (*Token, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Token), args.Error(1)
+}
+
+func (m *MockRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*Token, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Token), args.Error(1)
+}
+
+func (m *MockRepository) ListTokens(ctx context.Context, festivalID uuid.UUID, query ListTokensQuery) ([]Token, error) {
+	args := m.Called(ctx, festivalID, query)
+	return args.Get(0).([]Token), args.Error(1)
+}
+
+func (m *MockRepository) UpdateToken(ctx context.Context, token *Token) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchToken(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	args := m.Called(ctx, standID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandInfo), args.Error(1)
+}
+
+func (m *MockRepository) ListMenuProducts(ctx context.Context, standID uuid.UUID) ([]product.Product, error) {
+	args := m.Called(ctx, standID)
+	return args.Get(0).([]product.Product), args.Error(1)
+}
+
+func (m *MockRepository) ListReadyOrders(ctx context.Context, standID uuid.UUID, since time.Time, limit int) ([]ReadyOrder, error) {
+	args := m.Called(ctx, standID, since, limit)
+	return args.Get(0).([]ReadyOrder), args.Error(1)
+}
@@ -0,0 +1,269 @@
+package display
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/rs/zerolog/log"
+)
+
+// WaitTimes provides the estimated wait of the stands; satisfied by *order.WaitTimeService
+type WaitTimes interface {
+	GetStandWaitTime(ctx context.Context, festivalID, standID uuid.UUID) (*order.WaitTimeEstimate, error)
+}
+
+// PriceLists resolves the price list in effect at a stand; satisfied by
+// *product.PriceListService
+type PriceLists interface {
+	ActivePriceList(ctx context.Context, festivalID, standID uuid.UUID) (*product.PriceList, error)
+}
+
+// Disconnector closes the menu board connections of a revoked token on every instance;
+// satisfied by *realtime.Service
+type Disconnector interface {
+	DisconnectDisplay(ctx context.Context, festivalID, tokenID string, revocation interface{})
+}
+
+// Service issues the display tokens of the digital menu boards. An organizer creates a
+// token for a stand and sets it up on the board, which then reads the menu, the wait
+// time and the now-serving numbers of that stand only, until the token is revoked.
+type Service struct {
+	repo         Repository
+	waitTimes    WaitTimes
+	priceLists   PriceLists
+	disconnector Disconnector
+	now          func() time.Time
+}
+
+// NewService creates a new display token service
+func NewService(repo Repository, waitTimes WaitTimes) *Service {
+	return &Service{
+		repo:      repo,
+		waitTimes: waitTimes,
+		now:       time.Now,
+	}
+}
+
+// SetPriceLists shows the prices of the active price list, e.g. a happy hour, instead
+// of the base prices
+func (s *Service) SetPriceLists(priceLists PriceLists) {
+	s.priceLists = priceLists
+}
+
+// SetDisconnector closes the menu board connections of the tokens when they are revoked
+func (s *Service) SetDisconnector(disconnector Disconnector) {
+	s.disconnector = disconnector
+}
+
+// CreateToken creates a display token for a stand and returns it with its secret, which
+// is only shown once
+func (s *Service) CreateToken(ctx context.Context, festivalID uuid.UUID, createdBy *uuid.UUID, req CreateTokenRequest) (*CreatedToken, error) {
+	stand, err := s.repo.GetStandInfo(ctx, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil || stand.FestivalID != festivalID {
+		return nil, ErrStandNotFound
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	token := &Token{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		StandID:     req.StandID,
+		Name:        strings.TrimSpace(req.Name),
+		TokenHash:   hashSecret(secret),
+		TokenPrefix: secret[:len(TokenPrefix)+6],
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return &CreatedToken{Token: *token, DisplayToken: secret}, nil
+}
+
+// ListTokens lists the display tokens of a festival
+func (s *Service) ListTokens(ctx context.Context, festivalID uuid.UUID, query ListTokensQuery) ([]Token, error) {
+	return s.repo.ListTokens(ctx, festivalID, query)
+}
+
+// RevokeToken revokes a display token at once, e.g. when a board is stolen or its token
+// was shown on screen. Its open menu board connections are closed; revoking it again
+// changes nothing.
+func (s *Service) RevokeToken(ctx context.Context, festivalID, id uuid.UUID, revokedBy *uuid.UUID) (*Token, error) {
+	token, err := s.repo.GetToken(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrTokenNotFound
+	}
+	if token.RevokedAt != nil {
+		return token, nil
+	}
+
+	now := s.now()
+	token.RevokedAt = &now
+	token.RevokedBy = revokedBy
+	token.UpdatedAt = now
+	if err := s.repo.UpdateToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	if s.disconnector != nil {
+		s.disconnector.DisconnectDisplay(ctx, token.FestivalID.String(), token.ID.String(), Revocation{
+			TokenID: token.ID,
+			StandID: token.StandID,
+		})
+	}
+	return token, nil
+}
+
+// Authenticate resolves the token of a menu board. Revoked tokens fail with
+// ErrTokenRevoked, so the board can show that it needs a new one.
+func (s *Service) Authenticate(ctx context.Context, secret string) (*Token, error) {
+	if !strings.HasPrefix(secret, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := s.repo.GetTokenByHash(ctx, hashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidToken
+	}
+	if token.RevokedAt != nil {
+		return nil, ErrTokenRevoked
+	}
+
+	now := s.now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= touchInterval {
+		if err := s.repo.TouchToken(ctx, token.ID, now); err != nil {
+			log.Warn().Err(err).Str("display_token_id", token.ID.String()).Msg("Failed to record display token activity")
+		} else {
+			token.LastUsedAt = &now
+		}
+	}
+	return token, nil
+}
+
+// Menu returns the menu of the stand of a token, sold out products included so that
+// the board layout does not jump around
+func (s *Service) Menu(ctx context.Context, token *Token) (*Menu, error) {
+	stand, err := s.repo.GetStandInfo(ctx, token.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil {
+		return nil, ErrStandNotFound
+	}
+
+	products, err := s.repo.ListMenuProducts(ctx, token.StandID)
+	if err != nil {
+		return nil, err
+	}
+
+	var priceList *product.PriceList
+	if s.priceLists != nil {
+		priceList, err = s.priceLists.ActivePriceList(ctx, token.FestivalID, token.StandID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get price list: %w", err)
+		}
+	}
+
+	menu := &Menu{Stand: *stand, Items: make([]MenuItem, len(products))}
+	if priceList != nil {
+		menu.PriceListID = &priceList.ID
+	}
+	for i := range products {
+		p := &products[i]
+		price := p.Price
+		if priceList != nil {
+			price = priceList.PriceFor(p)
+		}
+		menu.Items[i] = MenuItem{
+			ID:          p.ID,
+			Name:        p.Name,
+			Description: p.Description,
+			Category:    p.Category,
+			Price:       price,
+			ImageURL:    p.ImageURL,
+			SoldOut:     p.Status == product.ProductStatusOutOfStock || (p.Stock != nil && *p.Stock <= 0),
+			Allergens:   p.Allergens,
+			DietaryTags: p.DietaryTags,
+		}
+	}
+	return menu, nil
+}
+
+// WaitTime returns the estimated wait at the stand of a token, zero without recent
+// orders
+func (s *Service) WaitTime(ctx context.Context, token *Token) (*WaitTime, error) {
+	wait := &WaitTime{StandID: token.StandID}
+	if s.waitTimes == nil {
+		return wait, nil
+	}
+
+	estimate, err := s.waitTimes.GetStandWaitTime(ctx, token.FestivalID, token.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if estimate != nil {
+		wait.EstimatedMinutes = estimate.EstimatedMinutes
+		wait.Level = estimate.Level
+		wait.UpdatedAt = &estimate.UpdatedAt
+	}
+	return wait, nil
+}
+
+// NowServing returns the numbers of the pickup orders of the stand of a token marked
+// ready in the last NowServingWindow
+func (s *Service) NowServing(ctx context.Context, token *Token) (*NowServing, error) {
+	ready, err := s.repo.ListReadyOrders(ctx, token.StandID, s.now().Add(-NowServingWindow), MaxNowServing)
+	if err != nil {
+		return nil, err
+	}
+
+	serving := &NowServing{StandID: token.StandID, Orders: make([]ServedOrder, len(ready))}
+	for i, o := range ready {
+		serving.Orders[i] = ServedOrder{Number: orderNumber(o.ID), ReadyAt: o.ReadyAt}
+	}
+	return serving, nil
+}
+
+// orderNumber is the short number printed on the order ticket and called out at the
+// counter, the start of the order ID
+func orderNumber(id uuid.UUID) string {
+	return "#" + strings.ToUpper(id.String()[:6])
+}
+
+func generateSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate display token: %w", err)
+	}
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
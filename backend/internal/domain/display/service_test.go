@@ -0,0 +1,182 @@
+package display
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWaitTimes struct {
+	estimates map[uuid.UUID]*order.WaitTimeEstimate
+}
+
+func (w *fakeWaitTimes) GetStandWaitTime(ctx context.Context, festivalID, standID uuid.UUID) (*order.WaitTimeEstimate, error) {
+	return w.estimates[standID], nil
+}
+
+type fakePriceLists struct {
+	active *product.PriceList
+}
+
+func (p *fakePriceLists) ActivePriceList(ctx context.Context, festivalID, standID uuid.UUID) (*product.PriceList, error) {
+	return p.active, nil
+}
+
+type fakeDisconnector struct {
+	tokenIDs []string
+}
+
+func (d *fakeDisconnector) DisconnectDisplay(ctx context.Context, festivalID, tokenID string, revocation interface{}) {
+	d.tokenIDs = append(d.tokenIDs, tokenID)
+}
+
+func newTestService(now *time.Time) (*Service, *MockRepository, *fakeWaitTimes) {
+	mockRepo := NewMockRepository()
+	waitTimes := &fakeWaitTimes{estimates: make(map[uuid.UUID]*order.WaitTimeEstimate)}
+	service := NewService(mockRepo, waitTimes)
+	service.now = func() time.Time { return *now }
+	return service, mockRepo, waitTimes
+}
+
+// expectStand returns a stand of the festival from the repository
+func expectStand(mockRepo *MockRepository, festivalID uuid.UUID, name string) *StandInfo {
+	stand := &StandInfo{ID: uuid.New(), FestivalID: festivalID, Name: name}
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	return stand
+}
+
+func TestService_RevokeTokenCutsOffBoard(t *testing.T) {
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	service, mockRepo, _ := newTestService(&now)
+	disconnector := &fakeDisconnector{}
+	service.SetDisconnector(disconnector)
+	festivalID := uuid.New()
+	stand := expectStand(mockRepo, festivalID, "Bar Nord")
+	organizerID := uuid.New()
+	ctx := context.Background()
+
+	_, err := service.CreateToken(ctx, uuid.New(), nil, CreateTokenRequest{StandID: stand.ID, Name: "Left screen"})
+	assert.ErrorIs(t, err, ErrStandNotFound)
+	mockRepo.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything)
+
+	stored := &Token{}
+	mockRepo.On("CreateToken", mock.Anything, mock.AnythingOfType("*display.Token")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Token) }).
+		Return(nil).Once()
+	created, err := service.CreateToken(ctx, festivalID, &organizerID, CreateTokenRequest{StandID: stand.ID, Name: " Left screen "})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.DisplayToken, TokenPrefix))
+	assert.True(t, strings.HasPrefix(created.DisplayToken, created.TokenPrefix))
+	assert.Equal(t, "Left screen", created.Name)
+	assert.NotContains(t, stored.TokenHash, created.DisplayToken)
+
+	mockRepo.On("GetTokenByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+	mockRepo.On("GetTokenByHash", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil)
+	mockRepo.On("GetToken", mock.Anything, festivalID, created.ID).Return(stored, nil)
+	mockRepo.On("GetToken", mock.Anything, mock.Anything, created.ID).Return(nil, nil)
+
+	mockRepo.On("TouchToken", mock.Anything, created.ID, now).Return(nil).Once()
+	token, err := service.Authenticate(ctx, created.DisplayToken)
+	require.NoError(t, err)
+	assert.Equal(t, stand.ID, token.StandID)
+	assert.Equal(t, &now, token.LastUsedAt)
+
+	_, err = service.Authenticate(ctx, TokenPrefix+"unknown")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.Authenticate(ctx, "pos_"+strings.TrimPrefix(created.DisplayToken, TokenPrefix))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	now = now.Add(time.Hour)
+	mockRepo.On("UpdateToken", mock.Anything, stored).Return(nil).Once()
+	revoked, err := service.RevokeToken(ctx, festivalID, created.ID, &organizerID)
+	require.NoError(t, err)
+	assert.Equal(t, &now, revoked.RevokedAt)
+	assert.Equal(t, &organizerID, revoked.RevokedBy)
+	assert.Equal(t, []string{created.ID.String()}, disconnector.tokenIDs)
+
+	_, err = service.Authenticate(ctx, created.DisplayToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// Revoking again changes nothing
+	later := now.Add(time.Minute)
+	now = later
+	revoked, err = service.RevokeToken(ctx, festivalID, created.ID, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, later, *revoked.RevokedAt)
+	assert.Len(t, disconnector.tokenIDs, 1)
+	mockRepo.AssertNumberOfCalls(t, "UpdateToken", 1)
+
+	_, err = service.RevokeToken(ctx, uuid.New(), created.ID, nil)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_MenuAppliesActivePriceList(t *testing.T) {
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	service, mockRepo, _ := newTestService(&now)
+	festivalID := uuid.New()
+	stand := expectStand(mockRepo, festivalID, "Bar Nord")
+	zero := 0
+	beer := product.Product{ID: uuid.New(), StandID: stand.ID, Name: "Pils", Price: 500, Category: product.ProductCategoryBeer, Status: product.ProductStatusActive}
+	cider := product.Product{ID: uuid.New(), StandID: stand.ID, Name: "Cider", Price: 600, Category: product.ProductCategoryBeer, Status: product.ProductStatusActive, Stock: &zero}
+	soda := product.Product{ID: uuid.New(), StandID: stand.ID, Name: "Cola", Price: 300, Category: product.ProductCategorySoft, Status: product.ProductStatusOutOfStock}
+	mockRepo.On("ListMenuProducts", mock.Anything, stand.ID).Return([]product.Product{beer, cider, soda}, nil)
+	token := &Token{ID: uuid.New(), FestivalID: festivalID, StandID: stand.ID}
+	ctx := context.Background()
+
+	menu, err := service.Menu(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "Bar Nord", menu.Stand.Name)
+	assert.Nil(t, menu.PriceListID)
+	require.Len(t, menu.Items, 3)
+	assert.Equal(t, int64(500), menu.Items[0].Price)
+	assert.False(t, menu.Items[0].SoldOut)
+	assert.True(t, menu.Items[1].SoldOut)
+	assert.True(t, menu.Items[2].SoldOut)
+
+	happyHour := &product.PriceList{ID: uuid.New(), Prices: []product.PriceListEntry{{ProductID: beer.ID, Price: 350}}}
+	service.SetPriceLists(&fakePriceLists{active: happyHour})
+
+	menu, err = service.Menu(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, &happyHour.ID, menu.PriceListID)
+	assert.Equal(t, int64(350), menu.Items[0].Price)
+	assert.Equal(t, int64(600), menu.Items[1].Price)
+}
+
+func TestService_WaitTimeAndNowServing(t *testing.T) {
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	service, mockRepo, waitTimes := newTestService(&now)
+	festivalID := uuid.New()
+	stand := &StandInfo{ID: uuid.New(), FestivalID: festivalID, Name: "Bar Nord"}
+	token := &Token{ID: uuid.New(), FestivalID: festivalID, StandID: stand.ID}
+	ctx := context.Background()
+
+	wait, err := service.WaitTime(ctx, token)
+	require.NoError(t, err)
+	assert.Zero(t, wait.EstimatedMinutes)
+	assert.Nil(t, wait.UpdatedAt)
+
+	waitTimes.estimates[stand.ID] = &order.WaitTimeEstimate{StandID: stand.ID, EstimatedMinutes: 12, Level: "high", UpdatedAt: now}
+	wait, err = service.WaitTime(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, 12, wait.EstimatedMinutes)
+	assert.Equal(t, "high", wait.Level)
+
+	orderID := uuid.MustParse("3f2a9c1e-0000-4000-8000-000000000000")
+	ready := []ReadyOrder{{ID: orderID, ReadyAt: now.Add(-time.Minute)}}
+	mockRepo.On("ListReadyOrders", mock.Anything, stand.ID, now.Add(-NowServingWindow), MaxNowServing).Return(ready, nil).Once()
+	serving, err := service.NowServing(ctx, token)
+	require.NoError(t, err)
+	require.Len(t, serving.Orders, 1)
+	assert.Equal(t, "#3F2A9C", serving.Orders[0].Number)
+	mockRepo.AssertExpectations(t)
+}
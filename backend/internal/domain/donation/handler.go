@@ -0,0 +1,355 @@
+package donation
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped round-up program, ledger and settlements,
+// which should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	roundUp := r.Group("/round-up")
+	{
+		roundUp.GET("", h.GetConfig)
+		roundUp.PUT("", h.UpdateConfig)
+		roundUp.GET("/donations", h.ListDonations)
+		roundUp.GET("/report", h.Report)
+		roundUp.GET("/settlements", h.ListSettlements)
+		roundUp.POST("/settlements", h.Settle)
+	}
+}
+
+// RegisterAttendeeRoutes registers the round-up opt-in of the attendee app, open to
+// every authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	r.GET("/me/round-up", h.GetMyOptIn)
+	r.PUT("/me/round-up", h.OptIn)
+	r.DELETE("/me/round-up", h.OptOut)
+}
+
+// GetConfig returns the round-up program of the festival
+// @Summary Get round-up program
+// @Description Whether the festival rounds the purchases of opted-in attendees up to the next euro, and the charity the round-ups go to
+// @Tags donations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Config} "Round-up program"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/round-up [get]
+func (h *Handler) GetConfig(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.service.GetConfig(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, config)
+}
+
+// UpdateConfig changes the round-up program of the festival
+// @Summary Update round-up program
+// @Description Enable or disable the round-ups and choose the charity. Enabling requires a charity. Disabling keeps the opt-ins for when the round-ups resume.
+// @Tags donations
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body UpdateConfigRequest true "Changes"
+// @Success 200 {object} response.Response{data=Config} "Round-up program"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/round-up [put]
+func (h *Handler) UpdateConfig(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	config, err := h.service.UpdateConfig(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, config)
+}
+
+// ListDonations lists the donation ledger of the festival
+// @Summary List round-up donations
+// @Description List the round-ups of the purchases of the festival, latest first, with the settlement that paid them to the charity
+// @Tags donations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Donation,meta=response.Meta} "Donations"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/round-up/donations [get]
+func (h *Handler) ListDonations(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := pagination(c)
+	donations, total, err := h.service.ListDonations(c.Request.Context(), festivalID, (page-1)*perPage, perPage)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, donations, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Report adds up the donations of the festival
+// @Summary Get donation report
+// @Description Round-ups of the festival in total and per day, the attendees rounding up now, and what was paid to the charity so far
+// @Tags donations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Report} "Donation report"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/round-up/report [get]
+func (h *Handler) Report(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// ListSettlements lists the payments of the donations to the charity
+// @Summary List donation settlements
+// @Description List the payments of the round-ups of the festival to its charity, oldest first
+// @Tags donations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Settlement} "Settlements"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/round-up/settlements [get]
+func (h *Handler) ListSettlements(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	settlements, err := h.service.ListSettlements(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, settlements)
+}
+
+// Settle records the payment of the unsettled donations to the charity
+// @Summary Settle donations
+// @Description Record that every donation not settled yet was paid to the charity, e.g. by the final transfer once the festival is over. The settlement adds them up and they are marked paid.
+// @Tags donations
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body SettleRequest true "Payment reference"
+// @Success 201 {object} response.Response{data=Settlement} "Settlement"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 409 {object} response.ErrorResponse "Nothing to settle"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/round-up/settlements [post]
+func (h *Handler) Settle(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req SettleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	settlement, err := h.service.Settle(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, settlement)
+}
+
+// GetMyOptIn returns the round-up of the attendee
+// @Summary Get my round-up
+// @Description Whether the festival collects round-ups and for which charity, whether the attendee opted in, and how much they donated
+// @Tags donations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=OptInStatus} "Round-up"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/round-up [get]
+func (h *Handler) GetMyOptIn(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	user := userID(c)
+	if user == nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	status, err := h.service.GetOptIn(c.Request.Context(), *user, festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, status)
+}
+
+// OptIn rounds up the purchases of the attendee
+// @Summary Opt in to round-ups
+// @Description Round every purchase of the attendee's wallet up to the next euro for the charity of the festival, from now on
+// @Tags donations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=OptInStatus} "Round-up"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No wallet in the festival"
+// @Failure 409 {object} response.ErrorResponse "The festival does not collect round-ups"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/round-up [put]
+func (h *Handler) OptIn(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	user := userID(c)
+	if user == nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	status, err := h.service.OptIn(c.Request.Context(), *user, festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, status)
+}
+
+// OptOut stops rounding up the purchases of the attendee
+// @Summary Opt out of round-ups
+// @Description Stop rounding up the purchases of the attendee's wallet. The donations made so far are kept.
+// @Tags donations
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 204 "Opted out"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/round-up [delete]
+func (h *Handler) OptOut(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	user := userID(c)
+	if user == nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	if err := h.service.OptOut(c.Request.Context(), *user, festivalID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRoundUpDisabled):
+		response.Conflict(c, "ROUND_UP_DISABLED", err.Error())
+	case errors.Is(err, ErrCharityRequired):
+		response.BadRequest(c, "CHARITY_REQUIRED", err.Error(), nil)
+	case errors.Is(err, ErrWalletNotFound):
+		response.NotFound(c, "No wallet in the festival")
+	case errors.Is(err, ErrNothingToSettle):
+		response.Conflict(c, "NOTHING_TO_SETTLE", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package donation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Round-up donation errors
+var (
+	ErrRoundUpDisabled = errors.New("the festival does not collect round-up donations")
+	ErrCharityRequired = errors.New("a charity is required to collect round-up donations")
+	ErrWalletNotFound  = errors.New("no wallet in the festival")
+	ErrNothingToSettle = errors.New("no donation left to settle")
+)
+
+// RoundUpUnit is what purchases are rounded up to, one euro in cents
+const RoundUpUnit = 100
+
+// Config is the round-up program of a festival: attendees who opt in round every
+// purchase up to the next euro for the charity the festival chose
+type Config struct {
+	FestivalID         uuid.UUID  `json:"festivalId" gorm:"type:uuid;primaryKey"`
+	Enabled            bool       `json:"enabled" gorm:"not null;default:false"`
+	CharityName        string     `json:"charityName"`
+	CharityDescription string     `json:"charityDescription,omitempty"`
+	CharityURL         string     `json:"charityUrl,omitempty"`
+	UpdatedBy          *uuid.UUID `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+}
+
+func (Config) TableName() string {
+	return "donation_configs"
+}
+
+// OptIn is an attendee rounding up the purchases of their wallet
+type OptIn struct {
+	WalletID   uuid.UUID `json:"walletId" gorm:"type:uuid;primaryKey"`
+	FestivalID uuid.UUID `json:"festivalId" gorm:"type:uuid;not null"`
+	UserID     uuid.UUID `json:"userId" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (OptIn) TableName() string {
+	return "donation_opt_ins"
+}
+
+// Donation is an entry of the donation ledger of a festival: the round-up of a purchase,
+// debited from the wallet by its own transaction
+type Donation struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	FestivalID    uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null"`
+	WalletID      uuid.UUID  `json:"walletId" gorm:"type:uuid;not null"`
+	PurchaseID    uuid.UUID  `json:"purchaseId" gorm:"type:uuid;not null"`    // Transaction of the purchase rounded up
+	TransactionID uuid.UUID  `json:"transactionId" gorm:"type:uuid;not null"` // Transaction debiting the round-up
+	Amount        int64      `json:"amount" gorm:"not null"`                  // Cents
+	CharityName   string     `json:"charityName" gorm:"not null"`
+	SettlementID  *uuid.UUID `json:"settlementId,omitempty" gorm:"type:uuid"` // Nil until paid to the charity
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+func (Donation) TableName() string {
+	return "donations"
+}
+
+// Settlement pays the donations accumulated since the previous settlement to the charity
+type Settlement struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null"`
+	CharityName string     `json:"charityName" gorm:"not null"`
+	Amount      int64      `json:"amount" gorm:"not null"` // Cents
+	Donations   int64      `json:"donations" gorm:"not null"`
+	Donors      int64      `json:"donors" gorm:"not null"`    // Distinct wallets
+	From        time.Time  `json:"from"`                      // First donation settled
+	To          time.Time  `json:"to"`                        // Last donation settled
+	Reference   string     `json:"reference" gorm:"not null"` // E.g. the bank transfer paying the charity
+	Note        string     `json:"note,omitempty"`
+	SettledBy   *uuid.UUID `json:"settledBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func (Settlement) TableName() string {
+	return "donation_settlements"
+}
+
+// Totals add up donations
+type Totals struct {
+	Donations int64 `json:"donations"`
+	Donors    int64 `json:"donors"` // Distinct wallets
+	Amount    int64 `json:"amount"`
+}
+
+// DayTotal adds up the donations of a day
+type DayTotal struct {
+	Day       string `json:"day"` // YYYY-MM-DD, UTC
+	Donations int64  `json:"donations"`
+	Amount    int64  `json:"amount"`
+}
+
+// Report is the donation report of a festival, to close the program with the charity
+type Report struct {
+	FestivalID  uuid.UUID    `json:"festivalId"`
+	Enabled     bool         `json:"enabled"`
+	CharityName string       `json:"charityName"`
+	OptIns      int64        `json:"optIns"` // Wallets rounding up now
+	Total       Totals       `json:"total"`
+	Settled     int64        `json:"settled"`   // Cents paid to the charity
+	Unsettled   int64        `json:"unsettled"` // Cents still to pay
+	Days        []DayTotal   `json:"days"`
+	Settlements []Settlement `json:"settlements"`
+	GeneratedAt time.Time    `json:"generatedAt"`
+}
+
+// Charity is the charity the round-ups of a festival go to
+type Charity struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// OptInStatus is the round-up of an attendee in a festival
+type OptInStatus struct {
+	Enabled   bool       `json:"enabled"` // The festival collects round-ups
+	Charity   *Charity   `json:"charity,omitempty"`
+	OptedIn   bool       `json:"optedIn"`
+	OptedInAt *time.Time `json:"optedInAt,omitempty"`
+	Donated   Totals     `json:"donated"` // Round-ups of the wallet so far
+}
+
+// UpdateConfigRequest changes the round-up program of a festival
+type UpdateConfigRequest struct {
+	Enabled            *bool   `json:"enabled,omitempty"`
+	CharityName        *string `json:"charityName,omitempty" binding:"omitempty,max=200"`
+	CharityDescription *string `json:"charityDescription,omitempty" binding:"omitempty,max=1000"`
+	CharityURL         *string `json:"charityUrl,omitempty" binding:"omitempty,url,max=500"`
+}
+
+// SettleRequest records the payment of the unsettled donations to the charity
+type SettleRequest struct {
+	Reference string `json:"reference" binding:"required,max=100"`
+	Note      string `json:"note" binding:"max=500"`
+}
@@ -0,0 +1,271 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoundUpTarget is the festival and charity the purchases of an opted-in wallet are
+// rounded up for
+type RoundUpTarget struct {
+	FestivalID  uuid.UUID
+	CharityName string
+}
+
+type Repository interface {
+	GetConfig(ctx context.Context, festivalID uuid.UUID) (*Config, error)
+	SaveConfig(ctx context.Context, config *Config) error
+
+	// GetUserWalletID returns the wallet of a user in a festival, nil when there is none
+	GetUserWalletID(ctx context.Context, userID, festivalID uuid.UUID) (*uuid.UUID, error)
+	// GetWalletFestivalID returns the festival of a wallet, nil when it does not exist
+	GetWalletFestivalID(ctx context.Context, walletID uuid.UUID) (*uuid.UUID, error)
+	GetOptIn(ctx context.Context, walletID uuid.UUID) (*OptIn, error)
+	SaveOptIn(ctx context.Context, optIn *OptIn) error
+	DeleteOptIn(ctx context.Context, walletID uuid.UUID) error
+	CountOptIns(ctx context.Context, festivalID uuid.UUID) (int64, error)
+	// GetRoundUpTarget returns where the purchases of a wallet are rounded up for, nil
+	// when the wallet did not opt in or its festival stopped collecting
+	GetRoundUpTarget(ctx context.Context, walletID uuid.UUID) (*RoundUpTarget, error)
+
+	CreateDonation(ctx context.Context, donation *Donation) error
+	ListDonations(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Donation, int64, error)
+	WalletTotals(ctx context.Context, walletID uuid.UUID) (*Totals, error)
+	FestivalTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error)
+	DayTotals(ctx context.Context, festivalID uuid.UUID) ([]DayTotal, error)
+
+	// Settle marks the unsettled donations of the festival paid by the settlement, which
+	// it fills with their totals and creates, in one transaction. It returns
+	// ErrNothingToSettle when every donation is settled already.
+	Settle(ctx context.Context, settlement *Settlement) error
+	ListSettlements(ctx context.Context, festivalID uuid.UUID) ([]Settlement, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetConfig(ctx context.Context, festivalID uuid.UUID) (*Config, error) {
+	var config Config
+	err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).First(&config).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get donation config: %w", err)
+	}
+	return &config, nil
+}
+
+func (r *repository) SaveConfig(ctx context.Context, config *Config) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "festival_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"enabled", "charity_name", "charity_description", "charity_url", "updated_by", "updated_at",
+		}),
+	}).Create(config).Error
+	if err != nil {
+		return fmt.Errorf("failed to save donation config: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetUserWalletID(ctx context.Context, userID, festivalID uuid.UUID) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("public.wallets").
+		Where("user_id = ? AND festival_id = ?", userID, festivalID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+func (r *repository) GetWalletFestivalID(ctx context.Context, walletID uuid.UUID) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("public.wallets").
+		Where("id = ?", walletID).
+		Pluck("festival_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet festival: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+func (r *repository) GetOptIn(ctx context.Context, walletID uuid.UUID) (*OptIn, error) {
+	var optIn OptIn
+	err := r.db.WithContext(ctx).Where("wallet_id = ?", walletID).First(&optIn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get round-up opt-in: %w", err)
+	}
+	return &optIn, nil
+}
+
+// SaveOptIn opts a wallet in, keeping the date of an existing opt-in
+func (r *repository) SaveOptIn(ctx context.Context, optIn *OptIn) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(optIn).Error
+	if err != nil {
+		return fmt.Errorf("failed to save round-up opt-in: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteOptIn(ctx context.Context, walletID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("wallet_id = ?", walletID).Delete(&OptIn{}).Error; err != nil {
+		return fmt.Errorf("failed to delete round-up opt-in: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) CountOptIns(ctx context.Context, festivalID uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&OptIn{}).Where("festival_id = ?", festivalID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count round-up opt-ins: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) GetRoundUpTarget(ctx context.Context, walletID uuid.UUID) (*RoundUpTarget, error) {
+	var targets []RoundUpTarget
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT o.festival_id, c.charity_name
+		FROM donation_opt_ins o
+		JOIN donation_configs c ON c.festival_id = o.festival_id AND c.enabled
+		WHERE o.wallet_id = ?`, walletID).
+		Scan(&targets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get round-up target: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return &targets[0], nil
+}
+
+func (r *repository) CreateDonation(ctx context.Context, donation *Donation) error {
+	if err := r.db.WithContext(ctx).Create(donation).Error; err != nil {
+		return fmt.Errorf("failed to create donation: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListDonations(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Donation, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Donation{}).Where("festival_id = ?", festivalID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count donations: %w", err)
+	}
+
+	var donations []Donation
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&donations).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list donations: %w", err)
+	}
+	return donations, total, nil
+}
+
+func (r *repository) WalletTotals(ctx context.Context, walletID uuid.UUID) (*Totals, error) {
+	return r.totals(ctx, "wallet_id = ?", walletID)
+}
+
+func (r *repository) FestivalTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error) {
+	return r.totals(ctx, "festival_id = ?", festivalID)
+}
+
+func (r *repository) totals(ctx context.Context, condition string, args ...interface{}) (*Totals, error) {
+	var totals Totals
+	err := r.db.WithContext(ctx).Model(&Donation{}).
+		Select("COUNT(*) AS donations, COUNT(DISTINCT wallet_id) AS donors, COALESCE(SUM(amount), 0) AS amount").
+		Where(condition, args...).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum donations: %w", err)
+	}
+	return &totals, nil
+}
+
+func (r *repository) DayTotals(ctx context.Context, festivalID uuid.UUID) ([]DayTotal, error) {
+	var days []DayTotal
+	err := r.db.WithContext(ctx).Model(&Donation{}).
+		Select("TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*) AS donations, SUM(amount) AS amount").
+		Where("festival_id = ?", festivalID).
+		Group("day").
+		Order("day").
+		Scan(&days).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum donations per day: %w", err)
+	}
+	return days, nil
+}
+
+func (r *repository) Settle(ctx context.Context, settlement *Settlement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the unsettled donations so that two settlements never pay the same one
+		var ids []uuid.UUID
+		err := tx.Model(&Donation{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("festival_id = ? AND settlement_id IS NULL", settlement.FestivalID).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock donations: %w", err)
+		}
+		if len(ids) == 0 {
+			return ErrNothingToSettle
+		}
+
+		var totals struct {
+			Totals
+			From time.Time
+			To   time.Time
+		}
+		err = tx.Model(&Donation{}).
+			Select("COUNT(*) AS donations, COUNT(DISTINCT wallet_id) AS donors, SUM(amount) AS amount, MIN(created_at) AS \"from\", MAX(created_at) AS \"to\"").
+			Where("id IN ?", ids).
+			Scan(&totals).Error
+		if err != nil {
+			return fmt.Errorf("failed to sum donations: %w", err)
+		}
+		settlement.Donations = totals.Donations
+		settlement.Donors = totals.Donors
+		settlement.Amount = totals.Amount
+		settlement.From = totals.From
+		settlement.To = totals.To
+
+		if err := tx.Create(settlement).Error; err != nil {
+			return fmt.Errorf("failed to create donation settlement: %w", err)
+		}
+		if err := tx.Model(&Donation{}).Where("id IN ?", ids).Update("settlement_id", settlement.ID).Error; err != nil {
+			return fmt.Errorf("failed to settle donations: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) ListSettlements(ctx context.Context, festivalID uuid.UUID) ([]Settlement, error) {
+	var settlements []Settlement
+	err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).Order("created_at").Find(&settlements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list donation settlements: %w", err)
+	}
+	return settlements, nil
+}
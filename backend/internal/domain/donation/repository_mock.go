@@ -0,0 +1,121 @@
+package donation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) GetConfig(ctx context.Context, festivalID uuid.UUID) (*Config, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Config), args.Error(1)
+}
+
+func (m *MockRepository) SaveConfig(ctx context.Context, config *Config) error {
+	args := m.Called(ctx, config)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetUserWalletID(ctx context.Context, userID, festivalID uuid.UUID) (*uuid.UUID, error) {
+	args := m.Called(ctx, userID, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetWalletFestivalID(ctx context.Context, walletID uuid.UUID) (*uuid.UUID, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetOptIn(ctx context.Context, walletID uuid.UUID) (*OptIn, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OptIn), args.Error(1)
+}
+
+func (m *MockRepository) SaveOptIn(ctx context.Context, optIn *OptIn) error {
+	args := m.Called(ctx, optIn)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteOptIn(ctx context.Context, walletID uuid.UUID) error {
+	args := m.Called(ctx, walletID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountOptIns(ctx context.Context, festivalID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) GetRoundUpTarget(ctx context.Context, walletID uuid.UUID) (*RoundUpTarget, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RoundUpTarget), args.Error(1)
+}
+
+func (m *MockRepository) CreateDonation(ctx context.Context, donation *Donation) error {
+	args := m.Called(ctx, donation)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListDonations(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Donation, int64, error) {
+	args := m.Called(ctx, festivalID, offset, limit)
+	return args.Get(0).([]Donation), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) WalletTotals(ctx context.Context, walletID uuid.UUID) (*Totals, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Totals), args.Error(1)
+}
+
+func (m *MockRepository) FestivalTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Totals), args.Error(1)
+}
+
+func (m *MockRepository) DayTotals(ctx context.Context, festivalID uuid.UUID) ([]DayTotal, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]DayTotal), args.Error(1)
+}
+
+func (m *MockRepository) Settle(ctx context.Context, settlement *Settlement) error {
+	args := m.Called(ctx, settlement)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListSettlements(ctx context.Context, festivalID uuid.UUID) ([]Settlement, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Settlement), args.Error(1)
+}
@@ -0,0 +1,267 @@
+package donation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service runs the round-up donations of the festivals. The wallet service asks it how
+// much each purchase is rounded up by, debits the round-up with its own transaction and
+// hands it back for the ledger; the organizers then settle the ledger with the charity.
+type Service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewService creates the round-up donation service
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+// GetConfig returns the round-up program of a festival, disabled when never configured
+func (s *Service) GetConfig(ctx context.Context, festivalID uuid.UUID) (*Config, error) {
+	config, err := s.repo.GetConfig(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return &Config{FestivalID: festivalID}, nil
+	}
+	return config, nil
+}
+
+// UpdateConfig changes the round-up program of a festival. It cannot be enabled without
+// a charity. Disabling it stops the round-ups and keeps the opt-ins for when it resumes.
+func (s *Service) UpdateConfig(ctx context.Context, festivalID uuid.UUID, req UpdateConfigRequest, updatedBy *uuid.UUID) (*Config, error) {
+	config, err := s.GetConfig(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		config.Enabled = *req.Enabled
+	}
+	if req.CharityName != nil {
+		config.CharityName = strings.TrimSpace(*req.CharityName)
+	}
+	if req.CharityDescription != nil {
+		config.CharityDescription = strings.TrimSpace(*req.CharityDescription)
+	}
+	if req.CharityURL != nil {
+		config.CharityURL = strings.TrimSpace(*req.CharityURL)
+	}
+	if config.Enabled && config.CharityName == "" {
+		return nil, ErrCharityRequired
+	}
+
+	now := s.now()
+	if config.CreatedAt.IsZero() {
+		config.CreatedAt = now
+	}
+	config.UpdatedBy = updatedBy
+	config.UpdatedAt = now
+	if err := s.repo.SaveConfig(ctx, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// GetOptIn returns the round-up of the wallet of an attendee in a festival
+func (s *Service) GetOptIn(ctx context.Context, userID, festivalID uuid.UUID) (*OptInStatus, error) {
+	config, err := s.GetConfig(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &OptInStatus{Enabled: config.Enabled}
+	if config.CharityName != "" {
+		status.Charity = &Charity{Name: config.CharityName, Description: config.CharityDescription, URL: config.CharityURL}
+	}
+
+	walletID, err := s.repo.GetUserWalletID(ctx, userID, festivalID)
+	if err != nil || walletID == nil {
+		return status, err
+	}
+	optIn, err := s.repo.GetOptIn(ctx, *walletID)
+	if err != nil {
+		return nil, err
+	}
+	if optIn != nil {
+		status.OptedIn = true
+		status.OptedInAt = &optIn.CreatedAt
+	}
+	donated, err := s.repo.WalletTotals(ctx, *walletID)
+	if err != nil {
+		return nil, err
+	}
+	status.Donated = *donated
+	return status, nil
+}
+
+// OptIn rounds up the purchases of the wallet of an attendee from now on
+func (s *Service) OptIn(ctx context.Context, userID, festivalID uuid.UUID) (*OptInStatus, error) {
+	config, err := s.GetConfig(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, ErrRoundUpDisabled
+	}
+
+	walletID, err := s.repo.GetUserWalletID(ctx, userID, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if walletID == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	optIn := &OptIn{WalletID: *walletID, FestivalID: festivalID, UserID: userID, CreatedAt: s.now()}
+	if err := s.repo.SaveOptIn(ctx, optIn); err != nil {
+		return nil, err
+	}
+	return s.GetOptIn(ctx, userID, festivalID)
+}
+
+// OptOut stops rounding up the purchases of the wallet of an attendee. The donations
+// made so far are kept.
+func (s *Service) OptOut(ctx context.Context, userID, festivalID uuid.UUID) error {
+	walletID, err := s.repo.GetUserWalletID(ctx, userID, festivalID)
+	if err != nil || walletID == nil {
+		return err
+	}
+	return s.repo.DeleteOptIn(ctx, *walletID)
+}
+
+// RoundUp returns how much a purchase of a wallet is rounded up by and the charity it
+// goes to, zero when the wallet did not opt in, its festival does not collect or the
+// amount is a whole euro already
+func (s *Service) RoundUp(ctx context.Context, walletID uuid.UUID, amount int64) (int64, string, error) {
+	roundUp := roundUpAmount(amount)
+	if roundUp == 0 {
+		return 0, "", nil
+	}
+
+	target, err := s.repo.GetRoundUpTarget(ctx, walletID)
+	if err != nil || target == nil {
+		return 0, "", err
+	}
+	return roundUp, target.CharityName, nil
+}
+
+// RecordRoundUp adds a round-up debited from a wallet to the donation ledger of its
+// festival
+func (s *Service) RecordRoundUp(ctx context.Context, walletID, purchaseID, transactionID uuid.UUID, amount int64, charity string) error {
+	festivalID, err := s.repo.GetWalletFestivalID(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if festivalID == nil {
+		return ErrWalletNotFound
+	}
+
+	return s.repo.CreateDonation(ctx, &Donation{
+		ID:            uuid.New(),
+		FestivalID:    *festivalID,
+		WalletID:      walletID,
+		PurchaseID:    purchaseID,
+		TransactionID: transactionID,
+		Amount:        amount,
+		CharityName:   charity,
+		CreatedAt:     s.now(),
+	})
+}
+
+// ListDonations lists the donation ledger of a festival, latest first
+func (s *Service) ListDonations(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Donation, int64, error) {
+	return s.repo.ListDonations(ctx, festivalID, offset, limit)
+}
+
+// Report adds up the donations of a festival, per day and in total, and what was paid
+// to the charity so far
+func (s *Service) Report(ctx context.Context, festivalID uuid.UUID) (*Report, error) {
+	config, err := s.GetConfig(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	optIns, err := s.repo.CountOptIns(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.FestivalTotals(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.repo.DayTotals(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := s.repo.ListSettlements(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		FestivalID:  festivalID,
+		Enabled:     config.Enabled,
+		CharityName: config.CharityName,
+		OptIns:      optIns,
+		Total:       *total,
+		Days:        days,
+		Settlements: settlements,
+		GeneratedAt: s.now().UTC(),
+	}
+	if report.Days == nil {
+		report.Days = []DayTotal{}
+	}
+	if report.Settlements == nil {
+		report.Settlements = []Settlement{}
+	}
+	for _, settlement := range settlements {
+		report.Settled += settlement.Amount
+	}
+	report.Unsettled = total.Amount - report.Settled
+	return report, nil
+}
+
+// Settle records that the donations not settled yet were paid to the charity of the
+// festival, e.g. by the final transfer once the festival is over
+func (s *Service) Settle(ctx context.Context, festivalID uuid.UUID, req SettleRequest, settledBy *uuid.UUID) (*Settlement, error) {
+	config, err := s.GetConfig(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if config.CharityName == "" {
+		return nil, ErrCharityRequired
+	}
+
+	settlement := &Settlement{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		CharityName: config.CharityName,
+		Reference:   strings.TrimSpace(req.Reference),
+		Note:        strings.TrimSpace(req.Note),
+		SettledBy:   settledBy,
+		CreatedAt:   s.now(),
+	}
+	if err := s.repo.Settle(ctx, settlement); err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+// ListSettlements lists the payments of the donations of a festival to its charity
+func (s *Service) ListSettlements(ctx context.Context, festivalID uuid.UUID) ([]Settlement, error) {
+	return s.repo.ListSettlements(ctx, festivalID)
+}
+
+// roundUpAmount returns what a purchase is rounded up by to reach the next euro
+func roundUpAmount(amount int64) int64 {
+	if amount <= 0 {
+		return 0
+	}
+	return (RoundUpUnit - amount%RoundUpUnit) % RoundUpUnit
+}
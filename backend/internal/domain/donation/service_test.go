@@ -0,0 +1,246 @@
+package donation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	mockRepo   *MockRepository
+	service    *Service
+	festivalID uuid.UUID
+	userID     uuid.UUID
+	walletID   uuid.UUID
+	config     *Config // As last saved, nil until then
+	now        time.Time
+}
+
+// newFixture gives the user a wallet in the festival. The repository keeps the round-up
+// program the service saves.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		mockRepo:   NewMockRepository(),
+		festivalID: uuid.New(),
+		userID:     uuid.New(),
+		walletID:   uuid.New(),
+		now:        time.Date(2026, 7, 18, 21, 0, 0, 0, time.UTC),
+	}
+	f.mockRepo.On("GetUserWalletID", mock.Anything, f.userID, f.festivalID).Return(&f.walletID, nil).Maybe()
+	f.mockRepo.On("GetUserWalletID", mock.Anything, mock.Anything, f.festivalID).Return(nil, nil).Maybe()
+	f.mockRepo.On("GetWalletFestivalID", mock.Anything, f.walletID).Return(&f.festivalID, nil).Maybe()
+	f.mockRepo.On("GetWalletFestivalID", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	f.mockRepo.On("SaveConfig", mock.Anything, mock.AnythingOfType("*donation.Config")).
+		Run(func(args mock.Arguments) {
+			saved := *args.Get(1).(*Config)
+			f.config = &saved
+		}).
+		Return(nil).Maybe()
+	f.service = NewService(f.mockRepo)
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+// expectConfig returns the round-up program as last saved on the next times lookups
+func (f *fixture) expectConfig(times int) {
+	var config *Config
+	if f.config != nil {
+		found := *f.config
+		config = &found
+	}
+	f.mockRepo.On("GetConfig", mock.Anything, f.festivalID).Return(config, nil).Times(times)
+}
+
+func (f *fixture) enable(t *testing.T) {
+	t.Helper()
+	f.expectConfig(1)
+	enabled, charity := true, "Red Cross"
+	_, err := f.service.UpdateConfig(context.Background(), f.festivalID, UpdateConfigRequest{
+		Enabled:     &enabled,
+		CharityName: &charity,
+	}, nil)
+	require.NoError(t, err)
+}
+
+func TestRoundUpAmount(t *testing.T) {
+	tests := []struct {
+		amount int64
+		want   int64
+	}{
+		{amount: 350, want: 50},
+		{amount: 1299, want: 1},
+		{amount: 1001, want: 99},
+		{amount: 500, want: 0},
+		{amount: 0, want: 0},
+		{amount: -250, want: 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, roundUpAmount(tt.amount), "amount %d", tt.amount)
+	}
+}
+
+func TestService_UpdateConfigRequiresCharity(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.expectConfig(2)
+	config, err := f.service.GetConfig(ctx, f.festivalID)
+	require.NoError(t, err)
+	assert.False(t, config.Enabled)
+
+	enabled := true
+	_, err = f.service.UpdateConfig(ctx, f.festivalID, UpdateConfigRequest{Enabled: &enabled}, nil)
+	assert.ErrorIs(t, err, ErrCharityRequired)
+	f.mockRepo.AssertNotCalled(t, "SaveConfig", mock.Anything, mock.Anything)
+
+	blank := "  "
+	f.enable(t)
+	require.NotNil(t, f.config)
+	assert.Equal(t, "Red Cross", f.config.CharityName)
+	f.expectConfig(1)
+	_, err = f.service.UpdateConfig(ctx, f.festivalID, UpdateConfigRequest{CharityName: &blank}, nil)
+	assert.ErrorIs(t, err, ErrCharityRequired)
+	f.mockRepo.AssertNumberOfCalls(t, "SaveConfig", 1)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_OptIn(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.expectConfig(1)
+	_, err := f.service.OptIn(ctx, f.userID, f.festivalID)
+	assert.ErrorIs(t, err, ErrRoundUpDisabled)
+
+	f.enable(t)
+	f.expectConfig(1)
+	_, err = f.service.OptIn(ctx, uuid.New(), f.festivalID)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	f.mockRepo.AssertNotCalled(t, "SaveOptIn", mock.Anything, mock.Anything)
+
+	stored := &OptIn{}
+	f.mockRepo.On("SaveOptIn", mock.Anything, mock.MatchedBy(func(optIn *OptIn) bool {
+		return optIn.WalletID == f.walletID && optIn.FestivalID == f.festivalID && optIn.UserID == f.userID
+	})).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*OptIn) }).
+		Return(nil).Once()
+	f.mockRepo.On("GetOptIn", mock.Anything, f.walletID).Return(stored, nil).Once()
+	f.mockRepo.On("WalletTotals", mock.Anything, f.walletID).Return(&Totals{}, nil).Twice()
+	f.expectConfig(2)
+	status, err := f.service.OptIn(ctx, f.userID, f.festivalID)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.True(t, status.OptedIn)
+	assert.Equal(t, &f.now, status.OptedInAt)
+	require.NotNil(t, status.Charity)
+	assert.Equal(t, "Red Cross", status.Charity.Name)
+
+	f.mockRepo.On("DeleteOptIn", mock.Anything, f.walletID).Return(nil).Once()
+	require.NoError(t, f.service.OptOut(ctx, f.userID, f.festivalID))
+	f.mockRepo.On("GetOptIn", mock.Anything, f.walletID).Return(nil, nil).Once()
+	f.expectConfig(1)
+	status, err = f.service.GetOptIn(ctx, f.userID, f.festivalID)
+	require.NoError(t, err)
+	assert.False(t, status.OptedIn)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_RoundUp(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	// Not opted in
+	f.mockRepo.On("GetRoundUpTarget", mock.Anything, f.walletID).Return(nil, nil).Once()
+	roundUp, _, err := f.service.RoundUp(ctx, f.walletID, 350)
+	require.NoError(t, err)
+	assert.Zero(t, roundUp)
+
+	f.mockRepo.On("GetRoundUpTarget", mock.Anything, f.walletID).
+		Return(&RoundUpTarget{FestivalID: f.festivalID, CharityName: "Red Cross"}, nil).Once()
+	roundUp, charity, err := f.service.RoundUp(ctx, f.walletID, 350)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), roundUp)
+	assert.Equal(t, "Red Cross", charity)
+
+	roundUp, _, err = f.service.RoundUp(ctx, f.walletID, 400)
+	require.NoError(t, err)
+	assert.Zero(t, roundUp, "a whole euro is not rounded up")
+	f.mockRepo.AssertNumberOfCalls(t, "GetRoundUpTarget", 2)
+
+	// The festival stops collecting: the opt-in has no target any more
+	f.mockRepo.On("GetRoundUpTarget", mock.Anything, f.walletID).Return(nil, nil).Once()
+	roundUp, _, err = f.service.RoundUp(ctx, f.walletID, 350)
+	require.NoError(t, err)
+	assert.Zero(t, roundUp)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_ReportAndSettle(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	f.enable(t)
+
+	f.expectConfig(1)
+	f.mockRepo.On("Settle", mock.Anything, mock.AnythingOfType("*donation.Settlement")).Return(ErrNothingToSettle).Once()
+	_, err := f.service.Settle(ctx, f.festivalID, SettleRequest{Reference: "TR-1"}, nil)
+	assert.ErrorIs(t, err, ErrNothingToSettle)
+
+	var donations []Donation
+	f.mockRepo.On("CreateDonation", mock.Anything, mock.AnythingOfType("*donation.Donation")).
+		Run(func(args mock.Arguments) { donations = append(donations, *args.Get(1).(*Donation)) }).
+		Return(nil)
+	require.NoError(t, f.service.RecordRoundUp(ctx, f.walletID, uuid.New(), uuid.New(), 50, "Red Cross"))
+	require.NoError(t, f.service.RecordRoundUp(ctx, f.walletID, uuid.New(), uuid.New(), 1, "Red Cross"))
+	err = f.service.RecordRoundUp(ctx, uuid.New(), uuid.New(), uuid.New(), 20, "Red Cross")
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+	require.Len(t, donations, 2)
+	assert.Equal(t, f.festivalID, donations[0].FestivalID)
+	assert.Equal(t, f.now, donations[0].CreatedAt)
+
+	// The repository settles the donations recorded so far
+	f.expectConfig(1)
+	f.mockRepo.On("Settle", mock.Anything, mock.AnythingOfType("*donation.Settlement")).
+		Run(func(args mock.Arguments) {
+			settlement := args.Get(1).(*Settlement)
+			for _, donation := range donations {
+				settlement.Donations++
+				settlement.Amount += donation.Amount
+			}
+			settlement.Donors = 1
+		}).
+		Return(nil).Once()
+	settlement, err := f.service.Settle(ctx, f.festivalID, SettleRequest{Reference: " TR-1 "}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(51), settlement.Amount)
+	assert.Equal(t, int64(2), settlement.Donations)
+	assert.Equal(t, f.festivalID, settlement.FestivalID)
+	assert.Equal(t, "TR-1", settlement.Reference)
+	assert.Equal(t, "Red Cross", settlement.CharityName)
+
+	days := []DayTotal{
+		{Day: "2026-07-18", Donations: 2, Amount: 51},
+		{Day: "2026-07-19", Donations: 1, Amount: 75},
+	}
+	f.expectConfig(1)
+	f.mockRepo.On("CountOptIns", mock.Anything, f.festivalID).Return(int64(1), nil).Once()
+	f.mockRepo.On("FestivalTotals", mock.Anything, f.festivalID).Return(&Totals{Donations: 3, Amount: 126, Donors: 1}, nil).Once()
+	f.mockRepo.On("DayTotals", mock.Anything, f.festivalID).Return(days, nil).Once()
+	f.mockRepo.On("ListSettlements", mock.Anything, f.festivalID).Return([]Settlement{*settlement}, nil).Once()
+	report, err := f.service.Report(ctx, f.festivalID)
+	require.NoError(t, err)
+	assert.True(t, report.Enabled)
+	assert.Equal(t, int64(1), report.OptIns)
+	assert.Equal(t, int64(3), report.Total.Donations)
+	assert.Equal(t, int64(126), report.Total.Amount)
+	assert.Equal(t, int64(51), report.Settled)
+	assert.Equal(t, int64(75), report.Unsettled)
+	assert.Equal(t, days, report.Days)
+	assert.Len(t, report.Settlements, 1)
+	f.mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,172 @@
+package duplicatecharge
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped review of duplicate charges, which
+// should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	charges := r.Group("/duplicate-charges")
+	{
+		charges.GET("", h.List)
+		charges.POST("/:chargeId/reverse", h.Reverse)
+		charges.POST("/:chargeId/dismiss", h.Dismiss)
+	}
+}
+
+// List lists the duplicate charges detected in the festival
+// @Summary List duplicate charges
+// @Description List the wallet charges detected as likely duplicates (same wallet, stand and amount within seconds), latest first
+// @Tags duplicate-charges
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(FLAGGED, REVERSED, DISMISSED)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]DuplicateCharge,meta=response.Meta} "Duplicate charges"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/duplicate-charges [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	status := ChargeStatus(c.Query("status"))
+	switch status {
+	case "", ChargeStatusFlagged, ChargeStatusReversed, ChargeStatusDismissed:
+	default:
+		response.BadRequest(c, "INVALID_STATUS", "Status must be FLAGGED, REVERSED or DISMISSED", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	charges, total, err := h.service.List(c.Request.Context(), festivalID, ChargeFilter{
+		Status: status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, charges, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Reverse refunds a flagged duplicate charge
+// @Summary Reverse duplicate charge
+// @Description Refund a flagged duplicate charge to the wallet and notify its holder
+// @Tags duplicate-charges
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param chargeId path string true "Duplicate charge ID" format(uuid)
+// @Param request body ResolveRequest false "Review note"
+// @Success 200 {object} response.Response{data=DuplicateCharge} "Reversed charge"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Duplicate charge not found"
+// @Failure 409 {object} response.ErrorResponse "Duplicate charge already resolved"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/duplicate-charges/{chargeId}/reverse [post]
+func (h *Handler) Reverse(c *gin.Context) {
+	h.resolve(c, h.service.Reverse)
+}
+
+// Dismiss closes the review of a legitimate repeat purchase
+// @Summary Dismiss duplicate charge
+// @Description Close the review of a flagged charge that was a legitimate repeat purchase
+// @Tags duplicate-charges
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param chargeId path string true "Duplicate charge ID" format(uuid)
+// @Param request body ResolveRequest false "Review note"
+// @Success 200 {object} response.Response{data=DuplicateCharge} "Dismissed charge"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Duplicate charge not found"
+// @Failure 409 {object} response.ErrorResponse "Duplicate charge already resolved"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/duplicate-charges/{chargeId}/dismiss [post]
+func (h *Handler) Dismiss(c *gin.Context) {
+	h.resolve(c, h.service.Dismiss)
+}
+
+type resolveFunc func(ctx context.Context, festivalID, id uuid.UUID, req ResolveRequest, staffID *uuid.UUID) (*DuplicateCharge, error)
+
+func (h *Handler) resolve(c *gin.Context, resolve resolveFunc) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	chargeID, err := uuid.Parse(c.Param("chargeId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid duplicate charge ID", nil)
+		return
+	}
+
+	var req ResolveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	var staffID *uuid.UUID
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		staffID = &id
+	}
+
+	charge, err := resolve(c.Request.Context(), festivalID, chargeID, req, staffID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrChargeNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, ErrAlreadyResolved):
+			response.Conflict(c, "ALREADY_RESOLVED", err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, charge)
+}
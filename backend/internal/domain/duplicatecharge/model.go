@@ -0,0 +1,136 @@
+package duplicatecharge
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Duplicate charge errors
+var (
+	ErrChargeNotFound  = errors.New("duplicate charge not found")
+	ErrAlreadyResolved = errors.New("duplicate charge was already resolved")
+)
+
+// Policy is what a festival does with detected duplicate charges, set as
+// duplicateChargePolicy in the festival settings
+type Policy string
+
+const (
+	PolicyReview      Policy = "review"       // Flag for review by the organizers (default)
+	PolicyAutoReverse Policy = "auto_reverse" // Refund the duplicate charge right away
+	PolicyOff         Policy = "off"          // No detection
+)
+
+// ParsePolicy returns the policy of a festival setting, defaulting to review
+func ParsePolicy(setting string) Policy {
+	switch Policy(setting) {
+	case PolicyAutoReverse, PolicyOff:
+		return Policy(setting)
+	default:
+		return PolicyReview
+	}
+}
+
+// Config tunes duplicate charge detection
+type Config struct {
+	Window   time.Duration // Maximum gap between a charge and its duplicate
+	Lookback time.Duration // How far back each scan looks for duplicates
+}
+
+// DefaultConfig returns the detection configuration used by the worker. The lookback
+// covers several scans, so charges synced late from offline terminals are still compared.
+func DefaultConfig() Config {
+	return Config{
+		Window:   5 * time.Second,
+		Lookback: time.Hour,
+	}
+}
+
+// ChargeStatus is the review status of a duplicate charge
+type ChargeStatus string
+
+const (
+	ChargeStatusFlagged   ChargeStatus = "FLAGGED"   // Waiting for review
+	ChargeStatusReversed  ChargeStatus = "REVERSED"  // Duplicate refunded to the wallet
+	ChargeStatusDismissed ChargeStatus = "DISMISSED" // Reviewed as a legitimate repeat purchase
+)
+
+// DuplicateCharge is a wallet purchase that is likely a duplicate of an earlier one:
+// same wallet, same stand and same amount within a few seconds
+type DuplicateCharge struct {
+	ID                     uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID             uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	WalletID               uuid.UUID    `json:"walletId" gorm:"type:uuid;not null"`
+	UserID                 *uuid.UUID   `json:"userId,omitempty" gorm:"type:uuid"` // Nil for anonymous wallets
+	StandID                uuid.UUID    `json:"standId" gorm:"type:uuid;not null"`
+	OriginalTransactionID  uuid.UUID    `json:"originalTransactionId" gorm:"type:uuid;not null"`
+	DuplicateTransactionID uuid.UUID    `json:"duplicateTransactionId" gorm:"type:uuid;not null;uniqueIndex"`
+	Amount                 int64        `json:"amount"`    // Charged twice, in cents (positive)
+	GapMs                  int64        `json:"gapMs"`     // Time between both charges
+	ChargedAt              time.Time    `json:"chargedAt"` // Time of the duplicate charge
+	Status                 ChargeStatus `json:"status" gorm:"default:'FLAGGED'"`
+	AutoReversed           bool         `json:"autoReversed"`
+	ReversalTransactionID  *uuid.UUID   `json:"reversalTransactionId,omitempty" gorm:"type:uuid"`
+	ResolvedBy             *uuid.UUID   `json:"resolvedBy,omitempty" gorm:"type:uuid"` // Nil when reversed automatically
+	ResolvedAt             *time.Time   `json:"resolvedAt,omitempty"`
+	Note                   string       `json:"note,omitempty"`
+	CreatedAt              time.Time    `json:"createdAt"`
+	UpdatedAt              time.Time    `json:"updatedAt"`
+}
+
+func (DuplicateCharge) TableName() string {
+	return "duplicate_charges"
+}
+
+// Candidate is a pair of purchases found by a scan, with what is needed to act on it
+type Candidate struct {
+	FestivalID             uuid.UUID
+	FestivalName           string
+	Policy                 string // duplicateChargePolicy festival setting
+	WalletID               uuid.UUID
+	UserID                 *uuid.UUID
+	Email                  string
+	Locale                 string
+	StandID                uuid.UUID
+	StandName              string
+	OriginalTransactionID  uuid.UUID
+	DuplicateTransactionID uuid.UUID
+	Amount                 int64 // Positive
+	OriginalAt             time.Time
+	DuplicateAt            time.Time
+	CurrencyName           string
+}
+
+// Notice tells the holder of a wallet about a duplicate charge
+type Notice struct {
+	To           string
+	Locale       string
+	FestivalID   uuid.UUID
+	FestivalName string
+	StandName    string
+	Amount       int64
+	CurrencyName string
+	ChargedAt    time.Time
+	Reversed     bool // Refunded, otherwise under review
+}
+
+// ChargeFilter filters the listed duplicate charges
+type ChargeFilter struct {
+	Status ChargeStatus
+	Offset int
+	Limit  int
+}
+
+// ResolveRequest is the review decision on a flagged charge
+type ResolveRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// ScanResult summarizes a detection scan
+type ScanResult struct {
+	Flagged  int `json:"flagged"`
+	Reversed int `json:"reversed"`
+	Notified int `json:"notified"`
+}
@@ -0,0 +1,146 @@
+package duplicatecharge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	FindCandidates(ctx context.Context, since time.Time, window time.Duration) ([]Candidate, error)
+	Create(ctx context.Context, charge *DuplicateCharge) (bool, error)
+	Get(ctx context.Context, festivalID, id uuid.UUID) (*DuplicateCharge, error)
+	List(ctx context.Context, festivalID uuid.UUID, filter ChargeFilter) ([]DuplicateCharge, int64, error)
+	Update(ctx context.Context, charge *DuplicateCharge) error
+	GetNoticeDetails(ctx context.Context, charge *DuplicateCharge) (*Candidate, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// candidateColumns selects a Candidate from the duplicate transaction d, its original o,
+// and their wallet, stand, festival and holder
+const candidateColumns = `
+	f.id AS festival_id, f.name AS festival_name, f.currency_name,
+	COALESCE(f.settings->>'duplicateChargePolicy', '') AS policy,
+	w.id AS wallet_id, w.user_id, COALESCE(u.email, '') AS email,
+	COALESCE(p.preferred_language, '') AS locale,
+	s.id AS stand_id, s.name AS stand_name,
+	o.id AS original_transaction_id, d.id AS duplicate_transaction_id,
+	-d.amount AS amount, o.created_at AS original_at, d.created_at AS duplicate_at`
+
+const candidateJoins = `
+	INNER JOIN public.wallets w ON w.id = d.wallet_id
+	INNER JOIN public.festivals f ON f.id = w.festival_id
+	INNER JOIN public.stands s ON s.id = d.stand_id
+	LEFT JOIN public.users u ON u.id = w.user_id
+	LEFT JOIN public.user_notification_preferences p ON p.user_id = w.user_id`
+
+// FindCandidates returns the completed purchases charged since since that follow an
+// earlier completed purchase of the same amount on the same wallet and at the same
+// stand by at most window, and were not recorded yet. A purchase repeated several
+// times is paired with the first one.
+func (r *repository) FindCandidates(ctx context.Context, since time.Time, window time.Duration) ([]Candidate, error) {
+	var candidates []Candidate
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (d.id) `+candidateColumns+`
+		FROM public.transactions d
+		INNER JOIN public.transactions o ON o.wallet_id = d.wallet_id
+			AND o.stand_id = d.stand_id
+			AND o.amount = d.amount
+			AND o.id <> d.id
+			AND o.type = 'PURCHASE' AND o.status = 'COMPLETED'
+			AND (o.created_at < d.created_at OR (o.created_at = d.created_at AND o.id < d.id))
+			AND o.created_at >= d.created_at - make_interval(secs => ?)
+		`+candidateJoins+`
+		WHERE d.type = 'PURCHASE' AND d.status = 'COMPLETED' AND d.stand_id IS NOT NULL
+			AND d.created_at >= ?
+			AND NOT EXISTS (SELECT 1 FROM public.duplicate_charges dc WHERE dc.duplicate_transaction_id = d.id)
+		ORDER BY d.id, o.created_at, o.id`,
+		window.Seconds(), since,
+	).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate charges: %w", err)
+	}
+	return candidates, nil
+}
+
+// Create records a duplicate charge, returning false when its transaction was already
+// recorded by a concurrent scan
+func (r *repository) Create(ctx context.Context, charge *DuplicateCharge) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "duplicate_transaction_id"}}, DoNothing: true}).
+		Create(charge)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create duplicate charge: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) Get(ctx context.Context, festivalID, id uuid.UUID) (*DuplicateCharge, error) {
+	var charge DuplicateCharge
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&charge).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get duplicate charge: %w", err)
+	}
+	return &charge, nil
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, filter ChargeFilter) ([]DuplicateCharge, int64, error) {
+	query := r.db.WithContext(ctx).Model(&DuplicateCharge{}).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count duplicate charges: %w", err)
+	}
+
+	var charges []DuplicateCharge
+	if err := query.Order("charged_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&charges).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list duplicate charges: %w", err)
+	}
+	return charges, total, nil
+}
+
+func (r *repository) Update(ctx context.Context, charge *DuplicateCharge) error {
+	if err := r.db.WithContext(ctx).Save(charge).Error; err != nil {
+		return fmt.Errorf("failed to update duplicate charge: %w", err)
+	}
+	return nil
+}
+
+// GetNoticeDetails loads the festival, stand and holder of a recorded charge to notify
+// the holder of a review decision
+func (r *repository) GetNoticeDetails(ctx context.Context, charge *DuplicateCharge) (*Candidate, error) {
+	var candidates []Candidate
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT `+candidateColumns+`
+		FROM public.transactions d
+		INNER JOIN public.transactions o ON o.id = ?
+		`+candidateJoins+`
+		WHERE d.id = ?`,
+		charge.OriginalTransactionID, charge.DuplicateTransactionID,
+	).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate charge details: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	return &candidates[0], nil
+}
@@ -0,0 +1,57 @@
+package duplicatecharge
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) FindCandidates(ctx context.Context, since time.Time, window time.Duration) ([]Candidate, error) {
+	args := m.Called(ctx, since, window)
+	return args.Get(0).([]Candidate), args.Error(1)
+}
+
+func (m *MockRepository) Create(ctx context.Context, charge *DuplicateCharge) (bool, error) {
+	args := m.Called(ctx, charge)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) Get(ctx context.Context, festivalID, id uuid.UUID) (*DuplicateCharge, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DuplicateCharge), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context, festivalID uuid.UUID, filter ChargeFilter) ([]DuplicateCharge, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]DuplicateCharge), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) Update(ctx context.Context, charge *DuplicateCharge) error {
+	args := m.Called(ctx, charge)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetNoticeDetails(ctx context.Context, charge *DuplicateCharge) (*Candidate, error) {
+	args := m.Called(ctx, charge)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Candidate), args.Error(1)
+}
@@ -0,0 +1,237 @@
+package duplicatecharge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeDetectDuplicates is the worker task scanning recent wallet charges for duplicates
+const TypeDetectDuplicates = "wallet:detect_duplicates"
+
+// reversalReason is the description of the refund reversing a duplicate charge
+const reversalReason = "Duplicate charge reversed"
+
+// Reverser refunds wallet purchases; satisfied by wallet.Service
+type Reverser interface {
+	RefundTransaction(ctx context.Context, transactionID uuid.UUID, reason string, staffID *uuid.UUID) (*wallet.Transaction, error)
+}
+
+// Notifier tells wallet holders about duplicate charges; satisfied by jobs.EmailQueue
+type Notifier interface {
+	NotifyDuplicateCharge(ctx context.Context, notice Notice) error
+}
+
+// Service detects duplicate wallet charges and reverses them automatically or after review
+type Service struct {
+	repo     Repository
+	reverser Reverser
+	notifier Notifier
+	config   Config
+	now      func() time.Time
+}
+
+// NewService creates a new duplicate charge service
+func NewService(repo Repository, reverser Reverser, config Config) *Service {
+	return &Service{
+		repo:     repo,
+		reverser: reverser,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// SetNotifier notifies wallet holders of flagged and reversed charges
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// HandleDetectDuplicates handles the periodic duplicate charge scan
+func (s *Service) HandleDetectDuplicates(ctx context.Context, t *asynq.Task) error {
+	result, err := s.Detect(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Flagged > 0 {
+		log.Warn().
+			Int("flagged", result.Flagged).
+			Int("reversed", result.Reversed).
+			Msg("Detected duplicate wallet charges")
+	}
+	return nil
+}
+
+// Detect records the duplicate charges made within the lookback, and reverses them in
+// festivals whose policy is auto_reverse. Festivals with the off policy are skipped.
+func (s *Service) Detect(ctx context.Context) (*ScanResult, error) {
+	candidates, err := s.repo.FindCandidates(ctx, s.now().Add(-s.config.Lookback), s.config.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScanResult{}
+	for _, candidate := range candidates {
+		policy := ParsePolicy(candidate.Policy)
+		if policy == PolicyOff {
+			continue
+		}
+
+		charge := &DuplicateCharge{
+			ID:                     uuid.New(),
+			FestivalID:             candidate.FestivalID,
+			WalletID:               candidate.WalletID,
+			UserID:                 candidate.UserID,
+			StandID:                candidate.StandID,
+			OriginalTransactionID:  candidate.OriginalTransactionID,
+			DuplicateTransactionID: candidate.DuplicateTransactionID,
+			Amount:                 candidate.Amount,
+			GapMs:                  candidate.DuplicateAt.Sub(candidate.OriginalAt).Milliseconds(),
+			ChargedAt:              candidate.DuplicateAt,
+			Status:                 ChargeStatusFlagged,
+			CreatedAt:              s.now(),
+			UpdatedAt:              s.now(),
+		}
+		created, err := s.repo.Create(ctx, charge)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			continue
+		}
+		result.Flagged++
+
+		if policy == PolicyAutoReverse {
+			if err := s.reverse(ctx, charge, nil, ""); err != nil {
+				// Left flagged for review
+				log.Error().Err(err).
+					Str("charge_id", charge.ID.String()).
+					Str("transaction_id", charge.DuplicateTransactionID.String()).
+					Msg("Failed to reverse duplicate charge")
+			} else {
+				charge.AutoReversed = true
+				if err := s.repo.Update(ctx, charge); err != nil {
+					return nil, err
+				}
+				result.Reversed++
+			}
+		}
+
+		if s.notify(ctx, &candidate, charge) {
+			result.Notified++
+		}
+	}
+
+	return result, nil
+}
+
+// List returns the duplicate charges of a festival, latest first
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, filter ChargeFilter) ([]DuplicateCharge, int64, error) {
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.repo.List(ctx, festivalID, filter)
+}
+
+// Reverse refunds a flagged charge after review and notifies the holder
+func (s *Service) Reverse(ctx context.Context, festivalID, id uuid.UUID, req ResolveRequest, staffID *uuid.UUID) (*DuplicateCharge, error) {
+	charge, err := s.getFlagged(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reverse(ctx, charge, staffID, req.Note); err != nil {
+		return nil, err
+	}
+
+	details, err := s.repo.GetNoticeDetails(ctx, charge)
+	if err != nil {
+		log.Warn().Err(err).Str("charge_id", charge.ID.String()).Msg("Failed to load duplicate charge details for notification")
+	} else if details != nil {
+		s.notify(ctx, details, charge)
+	}
+
+	return charge, nil
+}
+
+// Dismiss closes the review of a charge that was a legitimate repeat purchase
+func (s *Service) Dismiss(ctx context.Context, festivalID, id uuid.UUID, req ResolveRequest, staffID *uuid.UUID) (*DuplicateCharge, error) {
+	charge, err := s.getFlagged(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	charge.Status = ChargeStatusDismissed
+	charge.ResolvedBy = staffID
+	charge.ResolvedAt = &now
+	charge.Note = req.Note
+	charge.UpdatedAt = now
+	if err := s.repo.Update(ctx, charge); err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+func (s *Service) getFlagged(ctx context.Context, festivalID, id uuid.UUID) (*DuplicateCharge, error) {
+	charge, err := s.repo.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if charge == nil {
+		return nil, ErrChargeNotFound
+	}
+	if charge.Status != ChargeStatusFlagged {
+		return nil, ErrAlreadyResolved
+	}
+	return charge, nil
+}
+
+// reverse refunds the duplicate transaction of a charge and marks it reversed
+func (s *Service) reverse(ctx context.Context, charge *DuplicateCharge, staffID *uuid.UUID, note string) error {
+	refund, err := s.reverser.RefundTransaction(ctx, charge.DuplicateTransactionID, reversalReason, staffID)
+	if err != nil {
+		return fmt.Errorf("failed to reverse duplicate charge: %w", err)
+	}
+
+	now := s.now()
+	charge.Status = ChargeStatusReversed
+	charge.ReversalTransactionID = &refund.ID
+	charge.ResolvedBy = staffID
+	charge.ResolvedAt = &now
+	charge.Note = note
+	charge.UpdatedAt = now
+	return s.repo.Update(ctx, charge)
+}
+
+// notify emails the holder of the charged wallet, reporting whether the notice was sent.
+// Anonymous wallets have nobody to notify.
+func (s *Service) notify(ctx context.Context, details *Candidate, charge *DuplicateCharge) bool {
+	if s.notifier == nil || details.Email == "" {
+		return false
+	}
+
+	err := s.notifier.NotifyDuplicateCharge(ctx, Notice{
+		To:           details.Email,
+		Locale:       details.Locale,
+		FestivalID:   charge.FestivalID,
+		FestivalName: details.FestivalName,
+		StandName:    details.StandName,
+		Amount:       charge.Amount,
+		CurrencyName: details.CurrencyName,
+		ChargedAt:    charge.ChargedAt,
+		Reversed:     charge.Status == ChargeStatusReversed,
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("charge_id", charge.ID.String()).Msg("Failed to notify wallet holder of duplicate charge")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package duplicatecharge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReverser struct {
+	refunded []uuid.UUID
+	err      error
+}
+
+func (r *fakeReverser) RefundTransaction(ctx context.Context, transactionID uuid.UUID, reason string, staffID *uuid.UUID) (*wallet.Transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.refunded = append(r.refunded, transactionID)
+	return &wallet.Transaction{ID: uuid.New(), Type: wallet.TransactionTypeRefund, Reference: transactionID.String()}, nil
+}
+
+type fakeNotifier struct {
+	notices []Notice
+}
+
+func (n *fakeNotifier) NotifyDuplicateCharge(ctx context.Context, notice Notice) error {
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+func candidate(policy, email string) Candidate {
+	userID := uuid.New()
+	chargedAt := time.Date(2026, 7, 18, 21, 30, 2, 0, time.UTC)
+	return Candidate{
+		FestivalID:             uuid.New(),
+		FestivalName:           "Summer Fest",
+		Policy:                 policy,
+		WalletID:               uuid.New(),
+		UserID:                 &userID,
+		Email:                  email,
+		StandID:                uuid.New(),
+		StandName:              "Main Bar",
+		OriginalTransactionID:  uuid.New(),
+		DuplicateTransactionID: uuid.New(),
+		Amount:                 750,
+		OriginalAt:             chargedAt.Add(-1500 * time.Millisecond),
+		DuplicateAt:            chargedAt,
+	}
+}
+
+func newTestService(repo Repository, reverser Reverser, notifier Notifier) *Service {
+	service := NewService(repo, reverser, DefaultConfig())
+	service.SetNotifier(notifier)
+	now := time.Date(2026, 7, 18, 21, 31, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+// expectCreate records the charges created by a scan, by duplicate transaction
+func expectCreate(mockRepo *MockRepository) map[uuid.UUID]*DuplicateCharge {
+	charges := make(map[uuid.UUID]*DuplicateCharge)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*duplicatecharge.DuplicateCharge")).
+		Run(func(args mock.Arguments) {
+			charge := args.Get(1).(*DuplicateCharge)
+			charges[charge.DuplicateTransactionID] = charge
+		}).
+		Return(true, nil)
+	return charges
+}
+
+// flaggedCharge returns the charge a scan flags for a candidate
+func flaggedCharge(c Candidate) *DuplicateCharge {
+	return &DuplicateCharge{
+		ID:                     uuid.New(),
+		FestivalID:             c.FestivalID,
+		WalletID:               c.WalletID,
+		UserID:                 c.UserID,
+		StandID:                c.StandID,
+		OriginalTransactionID:  c.OriginalTransactionID,
+		DuplicateTransactionID: c.DuplicateTransactionID,
+		Amount:                 c.Amount,
+		ChargedAt:              c.DuplicateAt,
+		Status:                 ChargeStatusFlagged,
+	}
+}
+
+func TestDetect_Policies(t *testing.T) {
+	review := candidate("", "review@example.com")
+	autoReverse := candidate(string(PolicyAutoReverse), "auto@example.com")
+	off := candidate(string(PolicyOff), "off@example.com")
+	mockRepo := NewMockRepository()
+	reverser := &fakeReverser{}
+	notifier := &fakeNotifier{}
+	service := newTestService(mockRepo, reverser, notifier)
+
+	candidates := []Candidate{review, autoReverse, off}
+	mockRepo.On("FindCandidates", mock.Anything, service.now().Add(-time.Hour), DefaultConfig().Window).Return(candidates, nil)
+	charges := expectCreate(mockRepo)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*duplicatecharge.DuplicateCharge")).Return(nil)
+
+	result, err := service.Detect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ScanResult{Flagged: 2, Reversed: 1, Notified: 2}, result)
+
+	flagged := charges[review.DuplicateTransactionID]
+	require.NotNil(t, flagged)
+	assert.Equal(t, ChargeStatusFlagged, flagged.Status)
+	assert.Equal(t, int64(1500), flagged.GapMs)
+	assert.Equal(t, int64(750), flagged.Amount)
+
+	reversed := charges[autoReverse.DuplicateTransactionID]
+	require.NotNil(t, reversed)
+	assert.Equal(t, ChargeStatusReversed, reversed.Status)
+	assert.True(t, reversed.AutoReversed)
+	assert.NotNil(t, reversed.ReversalTransactionID)
+	assert.Nil(t, reversed.ResolvedBy)
+	assert.Equal(t, []uuid.UUID{autoReverse.DuplicateTransactionID}, reverser.refunded)
+
+	assert.NotContains(t, charges, off.DuplicateTransactionID)
+
+	require.Len(t, notifier.notices, 2)
+	assert.Equal(t, "review@example.com", notifier.notices[0].To)
+	assert.False(t, notifier.notices[0].Reversed)
+	assert.Equal(t, "auto@example.com", notifier.notices[1].To)
+	assert.True(t, notifier.notices[1].Reversed)
+	mockRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestDetect_RecordedChargesSkipped(t *testing.T) {
+	c := candidate(string(PolicyAutoReverse), "auto@example.com")
+	mockRepo := NewMockRepository()
+	reverser := &fakeReverser{}
+	notifier := &fakeNotifier{}
+	service := newTestService(mockRepo, reverser, notifier)
+
+	// A charge recorded by an earlier scan is neither reversed nor notified again
+	mockRepo.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything).Return([]Candidate{c}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*duplicatecharge.DuplicateCharge")).Return(false, nil).Once()
+
+	result, err := service.Detect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ScanResult{}, result)
+	assert.Empty(t, reverser.refunded)
+	assert.Empty(t, notifier.notices)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestDetect_FailedReversalStaysFlagged(t *testing.T) {
+	c := candidate(string(PolicyAutoReverse), "")
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo, &fakeReverser{err: errors.New("wallet frozen")}, &fakeNotifier{})
+	mockRepo.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything).Return([]Candidate{c}, nil)
+	charges := expectCreate(mockRepo)
+
+	result, err := service.Detect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ScanResult{Flagged: 1}, result)
+	assert.Equal(t, ChargeStatusFlagged, charges[c.DuplicateTransactionID].Status)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestReviewFlaggedCharge(t *testing.T) {
+	toReverse := candidate("", "holder@example.com")
+	mockRepo := NewMockRepository()
+	reverser := &fakeReverser{}
+	notifier := &fakeNotifier{}
+	service := newTestService(mockRepo, reverser, notifier)
+	organizerID := uuid.New()
+
+	flagged := flaggedCharge(toReverse)
+	mockRepo.On("Get", mock.Anything, flagged.FestivalID, flagged.ID).Return(flagged, nil).Once()
+	mockRepo.On("Update", mock.Anything, flagged).Return(nil).Once()
+	mockRepo.On("GetNoticeDetails", mock.Anything, flagged).Return(&toReverse, nil).Once()
+	reversed, err := service.Reverse(context.Background(), flagged.FestivalID, flagged.ID, ResolveRequest{Note: "Double tap"}, &organizerID)
+	require.NoError(t, err)
+	assert.Equal(t, ChargeStatusReversed, reversed.Status)
+	assert.False(t, reversed.AutoReversed)
+	assert.Equal(t, &organizerID, reversed.ResolvedBy)
+	assert.Equal(t, "Double tap", reversed.Note)
+	require.Len(t, notifier.notices, 1)
+	assert.True(t, notifier.notices[0].Reversed)
+	assert.Equal(t, "holder@example.com", notifier.notices[0].To)
+
+	mockRepo.On("Get", mock.Anything, flagged.FestivalID, flagged.ID).Return(reversed, nil).Once()
+	_, err = service.Reverse(context.Background(), flagged.FestivalID, flagged.ID, ResolveRequest{}, &organizerID)
+	assert.ErrorIs(t, err, ErrAlreadyResolved)
+	assert.Len(t, reverser.refunded, 1)
+
+	other := flaggedCharge(candidate("", ""))
+	mockRepo.On("Get", mock.Anything, mock.Anything, other.ID).Return(nil, nil).Once()
+	_, err = service.Dismiss(context.Background(), uuid.New(), other.ID, ResolveRequest{}, &organizerID)
+	assert.ErrorIs(t, err, ErrChargeNotFound)
+
+	mockRepo.On("Get", mock.Anything, other.FestivalID, other.ID).Return(other, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(c *DuplicateCharge) bool {
+		return c.ID == other.ID && c.Status == ChargeStatusDismissed
+	})).Return(nil).Once()
+	dismissed, err := service.Dismiss(context.Background(), other.FestivalID, other.ID, ResolveRequest{Note: "Two rounds"}, &organizerID)
+	require.NoError(t, err)
+	assert.Equal(t, ChargeStatusDismissed, dismissed.Status)
+	assert.Nil(t, dismissed.ReversalTransactionID)
+	assert.Len(t, reverser.refunded, 1)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestParsePolicy(t *testing.T) {
+	assert.Equal(t, PolicyReview, ParsePolicy(""))
+	assert.Equal(t, PolicyReview, ParsePolicy("unknown"))
+	assert.Equal(t, PolicyAutoReverse, ParsePolicy("auto_reverse"))
+	assert.Equal(t, PolicyOff, ParsePolicy("off"))
+}
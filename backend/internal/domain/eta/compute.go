@@ -0,0 +1,232 @@
+package eta
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SampleFeatures returns the features of a sample, with its time of day in loc
+func SampleFeatures(s Sample, loc *time.Location) Features {
+	return Features{
+		QueueLength:   float64(s.QueueLength),
+		ActiveStaff:   float64(s.ActiveStaff),
+		Items:         float64(s.Items),
+		PreparedItems: float64(s.PreparedItems),
+		LocalHour:     localHour(s.CreatedAt, loc),
+	}
+}
+
+// TrainStandModels trains the preparation time model of every stand with samples
+func TrainStandModels(samples []Sample, loc *time.Location, cfg Config, now time.Time) map[uuid.UUID]*StandModel {
+	byStand := make(map[uuid.UUID][]Sample)
+	for _, s := range samples {
+		byStand[s.StandID] = append(byStand[s.StandID], s)
+	}
+
+	models := make(map[uuid.UUID]*StandModel, len(byStand))
+	for standID, standSamples := range byStand {
+		models[standID] = TrainStandModel(standID, standSamples, loc, cfg, now)
+	}
+	return models
+}
+
+// TrainStandModel fits a ridge regression of the preparation time on the features of the
+// samples of a stand. Features are standardized so that the ridge weighs them alike; the
+// weights are converted back to seconds per unit of each feature. Stands with fewer than
+// MinSamples samples get their average preparation time.
+func TrainStandModel(standID uuid.UUID, samples []Sample, loc *time.Location, cfg Config, now time.Time) *StandModel {
+	model := &StandModel{
+		StandID:    standID,
+		Basis:      BasisAverage,
+		SampleSize: len(samples),
+		TrainedAt:  now,
+	}
+	if len(samples) == 0 {
+		return model
+	}
+
+	rows := make([][]float64, len(samples))
+	targets := make([]float64, len(samples))
+	for i, s := range samples {
+		rows[i] = SampleFeatures(s, loc).vector()
+		targets[i] = s.PrepSeconds
+		model.MeanSeconds += s.PrepSeconds
+	}
+	model.MeanSeconds /= float64(len(samples))
+
+	if len(samples) >= cfg.MinSamples {
+		if weights, ok := ridgeRegression(rows, targets, cfg.Ridge); ok {
+			model.Basis = BasisModel
+			model.Coefficients = &Coefficients{
+				Intercept:     weights[0],
+				QueueLength:   weights[1],
+				ActiveStaff:   weights[2],
+				Items:         weights[3],
+				PreparedItems: weights[4],
+				HourSin:       weights[5],
+				HourCos:       weights[6],
+			}
+		}
+	}
+
+	var squares float64
+	for i, row := range rows {
+		residual := targets[i] - model.predictSeconds(row)
+		squares += residual * residual
+	}
+	model.ErrorSeconds = roundTo(math.Sqrt(squares/float64(len(rows))), 1)
+	model.MeanSeconds = roundTo(model.MeanSeconds, 1)
+	return model
+}
+
+// Predict returns the expected preparation time of an order with the given features,
+// clamped to the configured range
+func (m *StandModel) Predict(f Features, cfg Config) time.Duration {
+	prep := time.Duration(m.predictSeconds(f.vector()) * float64(time.Second))
+	if prep < cfg.MinPrepTime {
+		return cfg.MinPrepTime
+	}
+	if prep > cfg.MaxPrepTime {
+		return cfg.MaxPrepTime
+	}
+	return prep
+}
+
+func (m *StandModel) predictSeconds(x []float64) float64 {
+	c := m.Coefficients
+	if c == nil {
+		return m.MeanSeconds
+	}
+	return c.Intercept + c.QueueLength*x[0] + c.ActiveStaff*x[1] + c.Items*x[2] +
+		c.PreparedItems*x[3] + c.HourSin*x[4] + c.HourCos*x[5]
+}
+
+// vector returns the regression inputs of the features, the time of day as a point on
+// the clock
+func (f Features) vector() []float64 {
+	angle := 2 * math.Pi * f.LocalHour / 24
+	return []float64{f.QueueLength, f.ActiveStaff, f.Items, f.PreparedItems, math.Sin(angle), math.Cos(angle)}
+}
+
+// ridgeRegression returns the intercept followed by the weight of each column of rows.
+// ok is false when the system cannot be solved.
+func ridgeRegression(rows [][]float64, targets []float64, ridge float64) ([]float64, bool) {
+	n, k := float64(len(rows)), len(rows[0])
+
+	means := make([]float64, k)
+	scales := make([]float64, k)
+	for _, row := range rows {
+		for j, v := range row {
+			means[j] += v
+		}
+	}
+	for j := range means {
+		means[j] /= n
+	}
+	for _, row := range rows {
+		for j, v := range row {
+			scales[j] += (v - means[j]) * (v - means[j]) / n
+		}
+	}
+	var meanTarget float64
+	for _, y := range targets {
+		meanTarget += y
+	}
+	meanTarget /= n
+
+	// Normal equations of the centered problem: (XᵀX + ridge·I) w = Xᵀy. Constant
+	// features keep a zero weight.
+	a := make([][]float64, k)
+	b := make([]float64, k)
+	for j := range a {
+		a[j] = make([]float64, k)
+		scales[j] = math.Sqrt(scales[j])
+		if scales[j] < 1e-9 {
+			scales[j] = 0
+		}
+	}
+	for i, row := range rows {
+		for j := 0; j < k; j++ {
+			xj := standardize(row[j], means[j], scales[j])
+			b[j] += xj * (targets[i] - meanTarget)
+			for l := 0; l < k; l++ {
+				a[j][l] += xj * standardize(row[l], means[l], scales[l])
+			}
+		}
+	}
+	for j := 0; j < k; j++ {
+		a[j][j] += ridge
+	}
+
+	standardized, ok := solve(a, b)
+	if !ok {
+		return nil, false
+	}
+
+	weights := make([]float64, k+1)
+	weights[0] = meanTarget
+	for j, w := range standardized {
+		if scales[j] == 0 {
+			continue
+		}
+		weights[j+1] = w / scales[j]
+		weights[0] -= weights[j+1] * means[j]
+	}
+	return weights, true
+}
+
+func standardize(v, mean, scale float64) float64 {
+	if scale == 0 {
+		return 0
+	}
+	return (v - mean) / scale
+}
+
+// solve solves a·x = b by Gaussian elimination with partial pivoting
+func solve(a [][]float64, b []float64) ([]float64, bool) {
+	k := len(b)
+	for col := 0; col < k; col++ {
+		pivot := col
+		for row := col + 1; row < k; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < k; row++ {
+			factor := a[row][col] / a[col][col]
+			for l := col; l < k; l++ {
+				a[row][l] -= factor * a[col][l]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, k)
+	for row := k - 1; row >= 0; row-- {
+		sum := b[row]
+		for l := row + 1; l < k; l++ {
+			sum -= a[row][l] * x[l]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, true
+}
+
+// localHour returns the time of day of t in loc, in hours
+func localHour(t time.Time, loc *time.Location) float64 {
+	local := t.In(loc)
+	return float64(local.Hour()) + float64(local.Minute())/60
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
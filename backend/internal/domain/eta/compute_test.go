@@ -0,0 +1,95 @@
+package eta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrainStandModel_LearnsQueueAndItemMix(t *testing.T) {
+	now := time.Date(2026, 7, 18, 20, 0, 0, 0, time.UTC)
+	standID := uuid.New()
+
+	// 60 seconds plus 45 per order in the queue, 10 per item and 90 more per prepared item
+	var samples []Sample
+	for i := 0; i < 60; i++ {
+		queue, items, prepared := int64(i%7), int64(1+i%4), int64(i%3)
+		samples = append(samples, Sample{
+			StandID:       standID,
+			CreatedAt:     now.Add(-time.Duration(i) * 17 * time.Minute),
+			PrepSeconds:   60 + 45*float64(queue) + 10*float64(items) + 90*float64(prepared),
+			QueueLength:   queue,
+			ActiveStaff:   2,
+			Items:         items,
+			PreparedItems: prepared,
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Ridge = 0.01
+	model := TrainStandModel(standID, samples, time.UTC, cfg, now)
+
+	assert.Equal(t, BasisModel, model.Basis)
+	assert.Equal(t, 60, model.SampleSize)
+	require.NotNil(t, model.Coefficients)
+	assert.InDelta(t, 45, model.Coefficients.QueueLength, 1)
+	assert.InDelta(t, 10, model.Coefficients.Items, 1)
+	assert.InDelta(t, 90, model.Coefficients.PreparedItems, 1)
+	assert.Zero(t, model.Coefficients.ActiveStaff, "constant features get no weight")
+	assert.Less(t, model.ErrorSeconds, 5.0)
+
+	prep := model.Predict(Features{QueueLength: 4, ActiveStaff: 2, Items: 2, PreparedItems: 1, LocalHour: 21}, cfg)
+	assert.InDelta(t, (60 + 180 + 20 + 90), prep.Seconds(), 5)
+}
+
+func TestTrainStandModel_FewSamplesUseAverage(t *testing.T) {
+	now := time.Date(2026, 7, 18, 20, 0, 0, 0, time.UTC)
+	standID := uuid.New()
+	samples := []Sample{
+		{StandID: standID, CreatedAt: now, PrepSeconds: 120, QueueLength: 1, Items: 1},
+		{StandID: standID, CreatedAt: now, PrepSeconds: 240, QueueLength: 5, Items: 3},
+	}
+
+	model := TrainStandModel(standID, samples, time.UTC, DefaultConfig(), now)
+
+	assert.Equal(t, BasisAverage, model.Basis)
+	assert.Nil(t, model.Coefficients)
+	assert.Equal(t, 180.0, model.MeanSeconds)
+	assert.Equal(t, 60.0, model.ErrorSeconds)
+	assert.Equal(t, 3*time.Minute, model.Predict(Features{QueueLength: 20}, DefaultConfig()))
+}
+
+func TestStandModel_PredictClamps(t *testing.T) {
+	cfg := DefaultConfig()
+	model := &StandModel{Basis: BasisModel, Coefficients: &Coefficients{Intercept: -100, QueueLength: 600}}
+
+	assert.Equal(t, cfg.MinPrepTime, model.Predict(Features{}, cfg))
+	assert.Equal(t, cfg.MaxPrepTime, model.Predict(Features{QueueLength: 50}, cfg))
+}
+
+func TestTrainStandModels_GroupsByStand(t *testing.T) {
+	now := time.Date(2026, 7, 18, 20, 0, 0, 0, time.UTC)
+	bar, food := uuid.New(), uuid.New()
+	samples := []Sample{
+		{StandID: bar, CreatedAt: now, PrepSeconds: 60},
+		{StandID: food, CreatedAt: now, PrepSeconds: 300},
+		{StandID: food, CreatedAt: now, PrepSeconds: 500},
+	}
+
+	models := TrainStandModels(samples, time.UTC, DefaultConfig(), now)
+
+	require.Len(t, models, 2)
+	assert.Equal(t, 60.0, models[bar].MeanSeconds)
+	assert.Equal(t, 400.0, models[food].MeanSeconds)
+	assert.Equal(t, 2, models[food].SampleSize)
+}
+
+func TestLocalHour(t *testing.T) {
+	brussels, err := time.LoadLocation("Europe/Brussels")
+	require.NoError(t, err)
+
+	assert.Equal(t, 22.5, localHour(time.Date(2026, 7, 18, 20, 30, 0, 0, time.UTC), brussels))
+}
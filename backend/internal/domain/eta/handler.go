@@ -0,0 +1,136 @@
+package eta
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the cart preparation time prediction, open to every
+// authenticated user of the festival
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/order-eta", h.Predict)
+}
+
+// RegisterOrganizerRoutes registers the model inspection routes, which should be
+// restricted to organizers
+func (h *Handler) RegisterOrganizerRoutes(r *gin.RouterGroup) {
+	models := r.Group("/order-eta/models")
+	{
+		models.GET("", h.ListModels)
+		models.POST("/train", h.Train)
+	}
+}
+
+// Predict predicts the preparation time of a cart
+// @Summary Predict order preparation time
+// @Description Predict how long a cart would take to be ready if it were ordered now, from the stand's preparation time model, its current queue and staff, the items and the time of day. Models are trained by the analytics worker.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body PredictRequest true "Prospective cart"
+// @Success 200 {object} response.Response{data=Prediction} "Predicted preparation time"
+// @Failure 400 {object} response.ErrorResponse "Invalid cart or product not on the menu"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No model for this stand yet"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-eta [post]
+func (h *Handler) Predict(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req PredictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	prediction, err := h.service.Predict(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, prediction)
+}
+
+// ListModels returns the preparation time models of the festival's stands
+// @Summary List preparation time models
+// @Description Get the preparation time model of each stand with recent orders: its weights, sample size and error
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]StandModel} "Stand models"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-eta/models [get]
+func (h *Handler) ListModels(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	models, err := h.service.ListModels(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, models)
+}
+
+// Train retrains the preparation time models of the festival's stands
+// @Summary Train preparation time models
+// @Description Retrain the preparation time models now instead of waiting for the analytics worker
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]StandModel} "Stand models"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-eta/models/train [post]
+func (h *Handler) Train(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	models, err := h.service.Train(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, models)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNoModel):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrUnknownProduct):
+		response.BadRequest(c, "UNKNOWN_PRODUCT", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package eta
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ETA errors
+var (
+	ErrNoModel        = errors.New("no preparation time model for this stand yet")
+	ErrUnknownProduct = errors.New("product is not on the menu of the stand")
+)
+
+// Basis tells how a preparation time was predicted
+const (
+	BasisModel   = "MODEL"   // Regression trained on the stand's orders
+	BasisAverage = "AVERAGE" // Too few orders to train a regression: average preparation time
+)
+
+// Config configures how preparation time models are trained
+type Config struct {
+	TrainingWindow time.Duration // Orders made ready in this window are the training samples
+	MinSamples     int           // Stands with fewer samples are predicted by their average
+	Ridge          float64       // Regularization keeping the weights of rare situations small
+	StaffWindow    time.Duration // Staff who processed an order in this window count as on duty
+	MinPrepTime    time.Duration // Predictions are clamped to this range
+	MaxPrepTime    time.Duration
+	CacheTTL       time.Duration // Cached models expire if the worker stops training them
+}
+
+// DefaultConfig returns the default ETA configuration
+func DefaultConfig() Config {
+	return Config{
+		TrainingWindow: 7 * 24 * time.Hour,
+		MinSamples:     30,
+		Ridge:          1,
+		StaffWindow:    30 * time.Minute,
+		MinPrepTime:    30 * time.Second,
+		MaxPrepTime:    90 * time.Minute,
+		CacheTTL:       2 * time.Hour,
+	}
+}
+
+// Features describe an order and the state of its stand when it was placed
+type Features struct {
+	QueueLength   float64 `json:"queueLength"`   // Paid orders of the stand waiting to be made ready
+	ActiveStaff   float64 `json:"activeStaff"`   // Staff who processed orders of the stand recently
+	Items         float64 `json:"items"`         // Units ordered
+	PreparedItems float64 `json:"preparedItems"` // Units of food and cocktails, which take longer to make
+	LocalHour     float64 `json:"localHour"`     // Time of day in the festival timezone, 0 to 24
+}
+
+// Sample is an order made ready, as retrieved by the repository
+type Sample struct {
+	StandID       uuid.UUID `gorm:"column:stand_id"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	PrepSeconds   float64   `gorm:"column:prep_seconds"`
+	QueueLength   int64     `gorm:"column:queue_length"`
+	ActiveStaff   int64     `gorm:"column:active_staff"`
+	Items         int64     `gorm:"column:items"`
+	PreparedItems int64     `gorm:"column:prepared_items"`
+}
+
+// StandState is the live state of a stand used to predict a new order
+type StandState struct {
+	QueueLength int64 `gorm:"column:queue_length"`
+	ActiveStaff int64 `gorm:"column:active_staff"`
+}
+
+// Coefficients are the weights of a linear preparation time model, in seconds
+type Coefficients struct {
+	Intercept     float64 `json:"intercept"`
+	QueueLength   float64 `json:"queueLength"`
+	ActiveStaff   float64 `json:"activeStaff"`
+	Items         float64 `json:"items"`
+	PreparedItems float64 `json:"preparedItems"`
+	HourSin       float64 `json:"hourSin"` // Time of day, as a point on the clock so that 23:00 is close to 01:00
+	HourCos       float64 `json:"hourCos"`
+}
+
+// StandModel is the preparation time model of a stand, cached by the analytics worker
+type StandModel struct {
+	StandID      uuid.UUID     `json:"standId"`
+	Basis        string        `json:"basis"`
+	Coefficients *Coefficients `json:"coefficients,omitempty"` // Nil for the average basis
+	MeanSeconds  float64       `json:"meanSeconds"`
+	ErrorSeconds float64       `json:"errorSeconds"` // Root mean square error on the samples
+	SampleSize   int           `json:"sampleSize"`
+	TrainedAt    time.Time     `json:"trainedAt"`
+}
+
+// CartItem is a product of a prospective order
+type CartItem struct {
+	ProductID uuid.UUID `json:"productId" binding:"required"`
+	Quantity  int       `json:"quantity" binding:"required,min=1,max=100"`
+}
+
+// PredictRequest represents the request to predict the preparation time of a cart
+type PredictRequest struct {
+	StandID uuid.UUID  `json:"standId" binding:"required"`
+	Items   []CartItem `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+// Prediction is the expected preparation time of a cart if it were ordered now
+type Prediction struct {
+	StandID          uuid.UUID `json:"standId"`
+	EstimatedSeconds int       `json:"estimatedSeconds"`
+	EstimatedMinutes int       `json:"estimatedMinutes"` // Rounded up, for "ready in ~7 min"
+	Basis            string    `json:"basis"`
+	Features         Features  `json:"features"`
+	SampleSize       int       `json:"sampleSize"`
+	TrainedAt        time.Time `json:"trainedAt"`
+}
@@ -0,0 +1,149 @@
+package eta
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"gorm.io/gorm"
+)
+
+// maxOpenOrderAge leaves out of the queue paid orders never marked ready
+const maxOpenOrderAge = 2 * time.Hour
+
+// preparedCategories are the product categories made to order
+var preparedCategories = []product.ProductCategory{product.ProductCategoryFood, product.ProductCategoryCocktail}
+
+type Repository interface {
+	GetSamples(ctx context.Context, festivalID uuid.UUID, since time.Time, staffWindow time.Duration) ([]Sample, error)
+	GetStandState(ctx context.Context, standID uuid.UUID, now time.Time, staffWindow time.Duration) (*StandState, error)
+	GetProductCategories(ctx context.Context, standID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]product.ProductCategory, error)
+	GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error)
+	GetFestivalsWithOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// GetSamples returns the paid orders of a festival made ready since the given time, with
+// the queue and the staff of their stand when they were placed
+func (r *repository) GetSamples(ctx context.Context, festivalID uuid.UUID, since time.Time, staffWindow time.Duration) ([]Sample, error) {
+	var samples []Sample
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.stand_id,
+			o.created_at,
+			EXTRACT(EPOCH FROM (o.ready_at - o.created_at)) as prep_seconds,
+			(SELECT COUNT(*) FROM orders q
+				WHERE q.stand_id = o.stand_id
+					AND q.status = @paid
+					AND q.created_at < o.created_at
+					AND q.created_at >= o.created_at - @openAge * interval '1 second'
+					AND (q.ready_at IS NULL OR q.ready_at > o.created_at)) as queue_length,
+			(SELECT COUNT(DISTINCT q.staff_id) FROM orders q
+				WHERE q.stand_id = o.stand_id
+					AND q.staff_id IS NOT NULL
+					AND q.created_at BETWEEN o.created_at - @staffWindow * interval '1 second' AND o.created_at) as active_staff,
+			(SELECT COALESCE(SUM((item->>'quantity')::int), 0) FROM jsonb_array_elements(o.items) item) as items,
+			(SELECT COALESCE(SUM((item->>'quantity')::int), 0) FROM jsonb_array_elements(o.items) item
+				JOIN products p ON p.id = (item->>'productId')::uuid
+				WHERE p.category IN @prepared) as prepared_items
+		FROM orders o
+		WHERE o.festival_id = @festival
+			AND o.status = @paid
+			AND o.ready_at >= @since
+			AND o.ready_at > o.created_at
+			AND o.ready_at <= o.created_at + @openAge * interval '1 second'`,
+		map[string]interface{}{
+			"festival":    festivalID,
+			"paid":        "PAID",
+			"since":       since,
+			"openAge":     maxOpenOrderAge.Seconds(),
+			"staffWindow": staffWindow.Seconds(),
+			"prepared":    preparedCategories,
+		},
+	).Scan(&samples).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preparation samples: %w", err)
+	}
+	return samples, nil
+}
+
+// GetStandState returns the paid orders waiting at a stand and its staff on duty
+func (r *repository) GetStandState(ctx context.Context, standID uuid.UUID, now time.Time, staffWindow time.Duration) (*StandState, error) {
+	var state StandState
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE o.status = @paid AND o.ready_at IS NULL AND o.created_at >= @openSince) as queue_length,
+			COUNT(DISTINCT o.staff_id) FILTER (WHERE o.created_at >= @staffSince) as active_staff
+		FROM orders o
+		WHERE o.stand_id = @stand
+			AND o.created_at >= LEAST(@openSince, @staffSince)`,
+		map[string]interface{}{
+			"stand":      standID,
+			"paid":       "PAID",
+			"openSince":  now.Add(-maxOpenOrderAge),
+			"staffSince": now.Add(-staffWindow),
+		},
+	).Scan(&state).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand state: %w", err)
+	}
+	return &state, nil
+}
+
+// GetProductCategories returns the category of the given products that are on the menu of a stand
+func (r *repository) GetProductCategories(ctx context.Context, standID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]product.ProductCategory, error) {
+	var rows []struct {
+		ID       uuid.UUID
+		Category product.ProductCategory
+	}
+	err := r.db.WithContext(ctx).Table("products").
+		Select("id, category").
+		Where("stand_id = ? AND id IN ?", standID, productIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product categories: %w", err)
+	}
+
+	categories := make(map[uuid.UUID]product.ProductCategory, len(rows))
+	for _, row := range rows {
+		categories[row.ID] = row.Category
+	}
+	return categories, nil
+}
+
+// GetFestivalTimezone returns the timezone of a festival, empty when it is unknown
+func (r *repository) GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	var timezones []string
+	err := r.db.WithContext(ctx).Table("festivals").
+		Where("id = ?", festivalID).
+		Pluck("timezone", &timezones).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to get festival timezone: %w", err)
+	}
+	if len(timezones) == 0 {
+		return "", nil
+	}
+	return timezones[0], nil
+}
+
+// GetFestivalsWithOrders returns the festivals with orders made ready since the given time
+func (r *repository) GetFestivalsWithOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("orders").
+		Distinct("festival_id").
+		Where("status = ? AND ready_at >= ?", "PAID", since).
+		Pluck("festival_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festivals with orders: %w", err)
+	}
+	return ids, nil
+}
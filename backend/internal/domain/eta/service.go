@@ -0,0 +1,218 @@
+package eta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Service trains per-stand preparation time models from paid orders and predicts the
+// preparation time of prospective orders from the models cached in Redis
+type Service struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	config      Config
+	now         func() time.Time
+}
+
+// NewService creates a new ETA service
+func NewService(repo Repository, redisClient *redis.Client, config Config) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// TrainETAModels trains the models of a festival, or of every festival with orders made
+// ready in the training window when festivalID is nil
+func (s *Service) TrainETAModels(ctx context.Context, festivalID *uuid.UUID) error {
+	if festivalID != nil {
+		_, err := s.Train(ctx, *festivalID)
+		return err
+	}
+	return s.TrainAll(ctx)
+}
+
+// TrainAll trains the models of every festival with orders made ready in the training window
+func (s *Service) TrainAll(ctx context.Context) error {
+	festivalIDs, err := s.repo.GetFestivalsWithOrders(ctx, s.now().Add(-s.config.TrainingWindow))
+	if err != nil {
+		return err
+	}
+
+	for _, festivalID := range festivalIDs {
+		if _, err := s.Train(ctx, festivalID); err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to train festival ETA models")
+		}
+	}
+	return nil
+}
+
+// Train trains and stores the models of a festival's stands
+func (s *Service) Train(ctx context.Context, festivalID uuid.UUID) ([]StandModel, error) {
+	now := s.now()
+	samples, err := s.repo.GetSamples(ctx, festivalID, now.Add(-s.config.TrainingWindow), s.config.StaffWindow)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := s.location(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	models := TrainStandModels(samples, loc, s.config, now)
+	if err := s.store(ctx, festivalID, models); err != nil {
+		return nil, err
+	}
+
+	result := make([]StandModel, 0, len(models))
+	for _, model := range models {
+		result = append(result, *model)
+	}
+	return result, nil
+}
+
+// ListModels returns the cached models of a festival's stands
+func (s *Service) ListModels(ctx context.Context, festivalID uuid.UUID) ([]StandModel, error) {
+	values, err := s.redisClient.HGetAll(ctx, s.keyBuilder.StandETAModelsKey(festivalID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ETA models: %w", err)
+	}
+
+	models := make([]StandModel, 0, len(values))
+	for _, value := range values {
+		var model StandModel
+		if err := json.Unmarshal([]byte(value), &model); err != nil {
+			continue
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// Predict returns the preparation time of a cart if it were ordered now, from the model
+// of the stand, its current queue and staff, and the time of day
+func (s *Service) Predict(ctx context.Context, festivalID uuid.UUID, req PredictRequest) (*Prediction, error) {
+	model, err := s.getModel(ctx, festivalID, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil || model.SampleSize == 0 {
+		return nil, ErrNoModel
+	}
+
+	productIDs := make([]uuid.UUID, len(req.Items))
+	for i, item := range req.Items {
+		productIDs[i] = item.ProductID
+	}
+	categories, err := s.repo.GetProductCategories(ctx, req.StandID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	state, err := s.repo.GetStandState(ctx, req.StandID, now, s.config.StaffWindow)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := s.location(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	features := Features{
+		QueueLength: float64(state.QueueLength),
+		ActiveStaff: float64(state.ActiveStaff),
+		LocalHour:   localHour(now, loc),
+	}
+	for _, item := range req.Items {
+		category, ok := categories[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProduct, item.ProductID)
+		}
+		features.Items += float64(item.Quantity)
+		if isPrepared(category) {
+			features.PreparedItems += float64(item.Quantity)
+		}
+	}
+
+	prep := model.Predict(features, s.config)
+	return &Prediction{
+		StandID:          req.StandID,
+		EstimatedSeconds: int(prep.Seconds()),
+		EstimatedMinutes: int(math.Ceil(prep.Minutes())),
+		Basis:            model.Basis,
+		Features:         features,
+		SampleSize:       model.SampleSize,
+		TrainedAt:        model.TrainedAt,
+	}, nil
+}
+
+// location returns the timezone the time of day of a festival is taken in
+func (s *Service) location(ctx context.Context, festivalID uuid.UUID) (*time.Location, error) {
+	timezone, err := s.repo.GetFestivalTimezone(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return tz.Load(timezone), nil
+}
+
+// getModel returns the cached model of a stand, or nil when there is none
+func (s *Service) getModel(ctx context.Context, festivalID, standID uuid.UUID) (*StandModel, error) {
+	value, err := s.redisClient.HGet(ctx, s.keyBuilder.StandETAModelsKey(festivalID), standID.String()).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand ETA model: %w", err)
+	}
+
+	var model StandModel
+	if err := json.Unmarshal([]byte(value), &model); err != nil {
+		return nil, fmt.Errorf("failed to decode stand ETA model: %w", err)
+	}
+	return &model, nil
+}
+
+// store replaces the festival models so stands without recent orders drop out
+func (s *Service) store(ctx context.Context, festivalID uuid.UUID, models map[uuid.UUID]*StandModel) error {
+	key := s.keyBuilder.StandETAModelsKey(festivalID)
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	for standID, model := range models {
+		data, err := json.Marshal(model)
+		if err != nil {
+			return fmt.Errorf("failed to encode stand ETA model: %w", err)
+		}
+		pipe.HSet(ctx, key, standID.String(), data)
+	}
+	pipe.Expire(ctx, key, s.config.CacheTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store ETA models: %w", err)
+	}
+	return nil
+}
+
+func isPrepared(category product.ProductCategory) bool {
+	for _, prepared := range preparedCategories {
+		if category == prepared {
+			return true
+		}
+	}
+	return false
+}
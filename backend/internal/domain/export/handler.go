@@ -0,0 +1,172 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+// Trailers sent after the last row, since the status code is already sent when a
+// stream fails halfway
+const (
+	TrailerStatus = "X-Export-Status" // "complete" or "error"
+	TrailerRows   = "X-Export-Rows"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped export routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	exports := r.Group("/exports")
+	{
+		exports.GET("/transactions", h.ExportTransactions)
+		exports.GET("/orders", h.ExportOrders)
+		exports.GET("/analytics-events", h.ExportAnalyticsEvents)
+	}
+}
+
+// ExportTransactions streams the wallet transactions of the festival
+// @Summary Export transactions
+// @Description Stream the festival's wallet transactions as CSV or JSON lines with chunked transfer encoding. The X-Export-Status trailer is "complete" once every row was sent.
+// @Tags exports
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param format query string false "csv (default) or jsonl"
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time, exclusive (RFC3339)"
+// @Param standId query string false "Stand ID" format(uuid)
+// @Success 200 {file} file "Transaction stream"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/exports/transactions [get]
+func (h *Handler) ExportTransactions(c *gin.Context) {
+	h.stream(c, DatasetTransactions)
+}
+
+// ExportOrders streams the orders of the festival
+// @Summary Export orders
+// @Description Stream the festival's stand orders as CSV or JSON lines with chunked transfer encoding. The X-Export-Status trailer is "complete" once every row was sent.
+// @Tags exports
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param format query string false "csv (default) or jsonl"
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time, exclusive (RFC3339)"
+// @Param standId query string false "Stand ID" format(uuid)
+// @Success 200 {file} file "Order stream"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/exports/orders [get]
+func (h *Handler) ExportOrders(c *gin.Context) {
+	h.stream(c, DatasetOrders)
+}
+
+// ExportAnalyticsEvents streams the analytics events of the festival
+// @Summary Export analytics events
+// @Description Stream the festival's analytics events as CSV or JSON lines with chunked transfer encoding. The X-Export-Status trailer is "complete" once every row was sent.
+// @Tags exports
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param format query string false "csv (default) or jsonl"
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time, exclusive (RFC3339)"
+// @Success 200 {file} file "Analytics event stream"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/exports/analytics-events [get]
+func (h *Handler) ExportAnalyticsEvents(c *gin.Context) {
+	h.stream(c, DatasetAnalyticsEvents)
+}
+
+func (h *Handler) stream(c *gin.Context, dataset Dataset) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query Query
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+	if raw := c.Query("standId"); raw != "" {
+		standID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_STAND_ID", "Invalid stand ID", nil)
+			return
+		}
+		query.StandID = &standID
+	}
+	if err := h.service.Validate(dataset, &query); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Large exports run far longer than the server write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn().Err(err).Msg("Failed to lift write deadline for export stream")
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", dataset, time.Now().UTC().Format("20060102T150405Z"), query.Format)
+	c.Header("Content-Type", query.Format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	c.Header("Trailer", TrailerStatus+", "+TrailerRows)
+	c.Status(http.StatusOK)
+
+	status := "complete"
+	out, err := NewRowWriter(query.Format, dataset, c.Writer)
+	var rows int64
+	if err == nil {
+		rows, err = h.service.Stream(c.Request.Context(), festivalID, dataset, query, out)
+	}
+	if err != nil {
+		status = "error"
+		log.Warn().
+			Err(err).
+			Str("festival_id", festivalID.String()).
+			Str("dataset", string(dataset)).
+			Int64("rows", rows).
+			Msg("Export stream interrupted")
+	}
+
+	c.Writer.Header().Set(TrailerStatus, status)
+	c.Writer.Header().Set(TrailerRows, strconv.FormatInt(rows, 10))
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidFormat):
+		response.BadRequest(c, "INVALID_FORMAT", "Format must be csv or jsonl", nil)
+	case errors.Is(err, ErrInvalidRange):
+		response.BadRequest(c, "INVALID_RANGE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidDataset):
+		response.BadRequest(c, "INVALID_DATASET", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,227 @@
+package export
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBatchSize is the number of rows fetched per page; at most one page is held in
+// memory while it is written to the client
+const DefaultBatchSize = 1000
+
+// Export errors
+var (
+	ErrInvalidFormat  = errors.New("invalid export format")
+	ErrInvalidRange   = errors.New("invalid time range")
+	ErrInvalidDataset = errors.New("invalid export dataset")
+)
+
+// Format is the encoding of an export stream
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatCSV || f == FormatJSONL
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Dataset is a table that can be exported
+type Dataset string
+
+const (
+	DatasetTransactions    Dataset = "transactions"
+	DatasetOrders          Dataset = "orders"
+	DatasetAnalyticsEvents Dataset = "analytics-events"
+)
+
+func (d Dataset) IsValid() bool {
+	switch d {
+	case DatasetTransactions, DatasetOrders, DatasetAnalyticsEvents:
+		return true
+	}
+	return false
+}
+
+// Query filters an export; rows are streamed in creation order
+type Query struct {
+	Format  Format     `form:"format"`
+	From    *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	StandID *uuid.UUID `form:"-"` // Transactions and orders only, parsed from standId
+}
+
+// Cursor is the position of the last row of a page; the next page starts after it
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Row is an exported record
+type Row interface {
+	CSVRecord() []string
+	Cursor() Cursor
+}
+
+// TransactionRow is an exported wallet transaction
+type TransactionRow struct {
+	ID            uuid.UUID  `json:"id"`
+	WalletID      uuid.UUID  `json:"walletId"`
+	Type          string     `json:"type"`
+	Amount        int64      `json:"amount"`
+	BalanceBefore int64      `json:"balanceBefore"`
+	BalanceAfter  int64      `json:"balanceAfter"`
+	Reference     string     `json:"reference"`
+	StandID       *uuid.UUID `json:"standId"`
+	StaffID       *uuid.UUID `json:"staffId"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+var transactionHeader = []string{"id", "wallet_id", "type", "amount", "balance_before", "balance_after",
+	"reference", "stand_id", "staff_id", "status", "created_at"}
+
+func (r TransactionRow) CSVRecord() []string {
+	return []string{
+		r.ID.String(),
+		r.WalletID.String(),
+		r.Type,
+		strconv.FormatInt(r.Amount, 10),
+		strconv.FormatInt(r.BalanceBefore, 10),
+		strconv.FormatInt(r.BalanceAfter, 10),
+		r.Reference,
+		uuidPtrToString(r.StandID),
+		uuidPtrToString(r.StaffID),
+		r.Status,
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (r TransactionRow) Cursor() Cursor {
+	return Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// OrderRow is an exported stand order; items and custom fields are kept as their JSON
+// arrays
+type OrderRow struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"userId"`
+	WalletID      uuid.UUID  `json:"walletId"`
+	StandID       uuid.UUID  `json:"standId"`
+	Items         RawJSON    `json:"items"`
+	TotalAmount   int64      `json:"totalAmount"`
+	Status        string     `json:"status"`
+	PaymentMethod string     `json:"paymentMethod"`
+	TransactionID *uuid.UUID `json:"transactionId"`
+	StaffID       *uuid.UUID `json:"staffId"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CustomFields  RawJSON    `json:"customFields"`
+}
+
+var orderHeader = []string{"id", "user_id", "wallet_id", "stand_id", "items", "total_amount",
+	"status", "payment_method", "transaction_id", "staff_id", "created_at", "custom_fields"}
+
+func (r OrderRow) CSVRecord() []string {
+	return []string{
+		r.ID.String(),
+		r.UserID.String(),
+		r.WalletID.String(),
+		r.StandID.String(),
+		string(r.Items),
+		strconv.FormatInt(r.TotalAmount, 10),
+		r.Status,
+		r.PaymentMethod,
+		uuidPtrToString(r.TransactionID),
+		uuidPtrToString(r.StaffID),
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+		string(r.CustomFields),
+	}
+}
+
+func (r OrderRow) Cursor() Cursor {
+	return Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// AnalyticsEventRow is an exported analytics event; data is kept as its JSON object
+type AnalyticsEventRow struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     *uuid.UUID `json:"userId"`
+	SessionID  string     `json:"sessionId"`
+	Type       string     `json:"type"`
+	Category   string     `json:"category"`
+	Action     string     `json:"action"`
+	Label      string     `json:"label"`
+	Value      float64    `json:"value"`
+	Data       RawJSON    `json:"data"`
+	Platform   string     `json:"platform"`
+	AppVersion string     `json:"appVersion"`
+	Timestamp  time.Time  `json:"timestamp"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+var analyticsEventHeader = []string{"id", "user_id", "session_id", "type", "category", "action", "label",
+	"value", "data", "platform", "app_version", "timestamp", "created_at"}
+
+func (r AnalyticsEventRow) CSVRecord() []string {
+	return []string{
+		r.ID.String(),
+		uuidPtrToString(r.UserID),
+		r.SessionID,
+		r.Type,
+		r.Category,
+		r.Action,
+		r.Label,
+		strconv.FormatFloat(r.Value, 'f', -1, 64),
+		string(r.Data),
+		r.Platform,
+		r.AppVersion,
+		r.Timestamp.UTC().Format(time.RFC3339Nano),
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (r AnalyticsEventRow) Cursor() Cursor {
+	return Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// RawJSON is a JSON document read as text from the database and written verbatim
+type RawJSON string
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+// csvHeader returns the CSV column names of a dataset
+func csvHeader(dataset Dataset) []string {
+	switch dataset {
+	case DatasetOrders:
+		return orderHeader
+	case DatasetAnalyticsEvents:
+		return analyticsEventHeader
+	default:
+		return transactionHeader
+	}
+}
+
+func uuidPtrToString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
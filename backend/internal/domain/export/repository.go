@@ -0,0 +1,102 @@
+package export
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository reads export pages with keyset pagination on (created_at, id), so each
+// page is an index range scan that costs the same at the start and the end of a
+// multi-million row export, and no transaction or connection is held between pages
+type Repository interface {
+	ListTransactions(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]TransactionRow, error)
+	ListOrders(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]OrderRow, error)
+	ListAnalyticsEvents(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]AnalyticsEventRow, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ListTransactions retrieves a page of the festival's wallet transactions
+func (r *repository) ListTransactions(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]TransactionRow, error) {
+	q := r.db.WithContext(ctx).
+		Table("public.transactions t").
+		Select(`t.id, t.wallet_id, t.type, t.amount, t.balance_before, t.balance_after,
+			COALESCE(t.reference, '') as reference, t.stand_id, t.staff_id, t.status, t.created_at`).
+		Joins("INNER JOIN public.wallets w ON t.wallet_id = w.id").
+		Where("w.festival_id = ?", festivalID)
+	if query.StandID != nil {
+		q = q.Where("t.stand_id = ?", *query.StandID)
+	}
+	q = page(q, "t", query, after, limit)
+
+	var rows []TransactionRow
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	return rows, nil
+}
+
+// ListOrders retrieves a page of the festival's orders
+func (r *repository) ListOrders(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]OrderRow, error) {
+	q := r.db.WithContext(ctx).
+		Table("public.orders o").
+		Select(`o.id, o.user_id, o.wallet_id, o.stand_id, o.items::text as items, o.total_amount,
+			o.status, o.payment_method, o.transaction_id, o.staff_id, o.created_at,
+			o.custom_fields::text as custom_fields`).
+		Where("o.festival_id = ?", festivalID)
+	if query.StandID != nil {
+		q = q.Where("o.stand_id = ?", *query.StandID)
+	}
+	q = page(q, "o", query, after, limit)
+
+	var rows []OrderRow
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return rows, nil
+}
+
+// ListAnalyticsEvents retrieves a page of the festival's analytics events
+func (r *repository) ListAnalyticsEvents(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]AnalyticsEventRow, error) {
+	q := r.db.WithContext(ctx).
+		Table("public.analytics_events e").
+		Select(`e.id, e.user_id, COALESCE(e.session_id, '') as session_id, e.type,
+			COALESCE(e.category, '') as category, COALESCE(e.action, '') as action,
+			COALESCE(e.label, '') as label, COALESCE(e.value, 0) as value,
+			COALESCE(e.data, '{}'::jsonb)::text as data, COALESCE(e.platform, '') as platform,
+			COALESCE(e.app_version, '') as app_version, e.timestamp, e.created_at`).
+		Where("e.festival_id = ?", festivalID)
+	q = page(q, "e", query, after, limit)
+
+	var rows []AnalyticsEventRow
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list analytics events: %w", err)
+	}
+
+	return rows, nil
+}
+
+// page restricts q to the time range of the query and the rows after the cursor
+func page(q *gorm.DB, alias string, query Query, after *Cursor, limit int) *gorm.DB {
+	if query.From != nil {
+		q = q.Where(alias+".created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		q = q.Where(alias+".created_at < ?", *query.To)
+	}
+	if after != nil {
+		q = q.Where("("+alias+".created_at, "+alias+".id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+	return q.Order(alias + ".created_at ASC, " + alias + ".id ASC").Limit(limit)
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo      Repository
+	batchSize int
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, batchSize: DefaultBatchSize}
+}
+
+// SetBatchSize overrides the number of rows fetched per page
+func (s *Service) SetBatchSize(size int) {
+	if size > 0 {
+		s.batchSize = size
+	}
+}
+
+// Validate checks an export request before the stream starts, defaulting to CSV
+func (s *Service) Validate(dataset Dataset, query *Query) error {
+	if !dataset.IsValid() {
+		return ErrInvalidDataset
+	}
+	if query.Format == "" {
+		query.Format = FormatCSV
+	}
+	if !query.Format.IsValid() {
+		return ErrInvalidFormat
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+// Stream writes every row of a dataset matching the query to out, page by page, and
+// returns the number of rows written. Each page is flushed to the client before the
+// next one is read, so a slow client blocks the export instead of rows piling up in
+// memory, and a disconnected client cancels ctx and stops it.
+func (s *Service) Stream(ctx context.Context, festivalID uuid.UUID, dataset Dataset, query Query, out RowWriter) (int64, error) {
+	var written int64
+	var after *Cursor
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		page, err := s.page(ctx, festivalID, dataset, query, after)
+		if err != nil {
+			return written, err
+		}
+
+		for _, row := range page {
+			if err := out.Write(row); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if err := out.Flush(); err != nil {
+			return written, err
+		}
+
+		if len(page) < s.batchSize {
+			return written, nil
+		}
+		cursor := page[len(page)-1].Cursor()
+		after = &cursor
+	}
+}
+
+// page reads the page of a dataset following the cursor
+func (s *Service) page(ctx context.Context, festivalID uuid.UUID, dataset Dataset, query Query, after *Cursor) ([]Row, error) {
+	switch dataset {
+	case DatasetTransactions:
+		rows, err := s.repo.ListTransactions(ctx, festivalID, query, after, s.batchSize)
+		return toRows(rows), err
+	case DatasetOrders:
+		rows, err := s.repo.ListOrders(ctx, festivalID, query, after, s.batchSize)
+		return toRows(rows), err
+	case DatasetAnalyticsEvents:
+		rows, err := s.repo.ListAnalyticsEvents(ctx, festivalID, query, after, s.batchSize)
+		return toRows(rows), err
+	default:
+		return nil, ErrInvalidDataset
+	}
+}
+
+func toRows[T Row](page []T) []Row {
+	rows := make([]Row, len(page))
+	for i := range page {
+		rows[i] = page[i]
+	}
+	return rows
+}
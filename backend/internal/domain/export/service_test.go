@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository serves orders from memory, which are already in (created_at, id) order
+type fakeRepository struct {
+	orders []OrderRow
+	calls  []*Cursor
+}
+
+func (r *fakeRepository) ListTransactions(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]TransactionRow, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) ListOrders(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]OrderRow, error) {
+	r.calls = append(r.calls, after)
+	start := 0
+	if after != nil {
+		for i, o := range r.orders {
+			if o.ID == after.ID {
+				start = i + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(r.orders) {
+		end = len(r.orders)
+	}
+	return r.orders[start:end], nil
+}
+
+func (r *fakeRepository) ListAnalyticsEvents(ctx context.Context, festivalID uuid.UUID, query Query, after *Cursor, limit int) ([]AnalyticsEventRow, error) {
+	return nil, nil
+}
+
+func newOrders(n int) []OrderRow {
+	start := time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+	orders := make([]OrderRow, n)
+	for i := range orders {
+		orders[i] = OrderRow{
+			ID:            uuid.New(),
+			StandID:       uuid.New(),
+			Items:         RawJSON(`[{"productId":"p1","quantity":2}]`),
+			CustomFields:  RawJSON(`[{"key":"table","label":"Table","value":"12"}]`),
+			TotalAmount:   int64(500 + i),
+			Status:        "PAID",
+			PaymentMethod: "wallet",
+			CreatedAt:     start.Add(time.Duration(i) * time.Second),
+		}
+	}
+	return orders
+}
+
+// TestStream_PagesWithCursor tests that every row is written once, page by page
+func TestStream_PagesWithCursor(t *testing.T) {
+	repo := &fakeRepository{orders: newOrders(5)}
+	service := NewService(repo)
+	service.SetBatchSize(2)
+
+	var buf bytes.Buffer
+	out, err := NewRowWriter(FormatJSONL, DatasetOrders, &buf)
+	require.NoError(t, err)
+
+	written, err := service.Stream(context.Background(), uuid.New(), DatasetOrders, Query{}, out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), written)
+
+	// Three pages, each starting after the last row of the previous one
+	require.Len(t, repo.calls, 3)
+	assert.Nil(t, repo.calls[0])
+	assert.Equal(t, repo.orders[1].ID, repo.calls[1].ID)
+	assert.Equal(t, repo.orders[3].ID, repo.calls[2].ID)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, repo.orders[0].ID.String(), first["id"])
+	assert.Equal(t, float64(500), first["totalAmount"])
+	assert.IsType(t, []interface{}{}, first["items"], "items are embedded as JSON, not as a string")
+}
+
+// TestStream_CancelledContext tests that a disconnected client stops the export
+func TestStream_CancelledContext(t *testing.T) {
+	repo := &fakeRepository{orders: newOrders(3)}
+	service := NewService(repo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out, err := NewRowWriter(FormatCSV, DatasetOrders, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	written, err := service.Stream(ctx, uuid.New(), DatasetOrders, Query{}, out)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, written)
+	assert.Empty(t, repo.calls)
+}
+
+// TestValidate tests the export request checks made before the stream starts
+func TestValidate(t *testing.T) {
+	service := NewService(&fakeRepository{})
+	from := time.Now()
+	to := from.Add(-time.Hour)
+
+	query := Query{}
+	require.NoError(t, service.Validate(DatasetOrders, &query))
+	assert.Equal(t, FormatCSV, query.Format)
+
+	assert.ErrorIs(t, service.Validate(DatasetOrders, &Query{Format: "xlsx"}), ErrInvalidFormat)
+	assert.ErrorIs(t, service.Validate(DatasetOrders, &Query{From: &from, To: &to}), ErrInvalidRange)
+	assert.ErrorIs(t, service.Validate(Dataset("users"), &Query{}), ErrInvalidDataset)
+}
+
+// TestHandler_StreamsCSVWithTrailers tests the chunked CSV response and its completion trailers
+func TestHandler_StreamsCSVWithTrailers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &fakeRepository{orders: newOrders(3)}
+	service := NewService(repo)
+	service.SetBatchSize(2)
+	handler := NewHandler(service)
+
+	festivalID := uuid.New()
+	router := gin.New()
+	group := router.Group("/festivals/:id", func(c *gin.Context) {
+		c.Set("festival_id", festivalID.String())
+	})
+	handler.RegisterRoutes(group)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/festivals/" + festivalID.String() + "/exports/orders?format=csv")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, orderHeader, records[0])
+	assert.Equal(t, repo.orders[2].ID.String(), records[3][0])
+	assert.Equal(t, `[{"productId":"p1","quantity":2}]`, records[1][4])
+	assert.Equal(t, `[{"key":"table","label":"Table","value":"12"}]`, records[1][11])
+
+	// Trailers are available once the body has been read
+	assert.Equal(t, "complete", resp.Trailer.Get(TrailerStatus))
+	assert.Equal(t, "3", resp.Trailer.Get(TrailerRows))
+}
+
+// TestHandler_InvalidFormat tests that request errors are reported before streaming
+func TestHandler_InvalidFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	group := router.Group("/festivals/:id", func(c *gin.Context) {
+		c.Set("festival_id", uuid.New().String())
+	})
+	NewHandler(NewService(&fakeRepository{})).RegisterRoutes(group)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/festivals/x/exports/transactions?format=xml", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_FORMAT")
+}
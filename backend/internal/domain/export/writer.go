@@ -0,0 +1,78 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// RowWriter encodes rows onto an export stream
+type RowWriter interface {
+	Write(row Row) error
+	// Flush writes buffered rows through to the client
+	Flush() error
+}
+
+// NewRowWriter creates a writer encoding rows of a dataset in the given format. CSV
+// streams start with the header of the dataset.
+func NewRowWriter(format Format, dataset Dataset, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader(dataset)); err != nil {
+			return nil, err
+		}
+		return &csvRowWriter{csv: cw, out: w}, nil
+	case FormatJSONL:
+		buf := bufio.NewWriter(w)
+		return &jsonlRowWriter{enc: json.NewEncoder(buf), buf: buf, out: w}, nil
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+type csvRowWriter struct {
+	csv *csv.Writer
+	out io.Writer
+}
+
+func (w *csvRowWriter) Write(row Row) error {
+	return w.csv.Write(row.CSVRecord())
+}
+
+func (w *csvRowWriter) Flush() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	flushHTTP(w.out)
+	return nil
+}
+
+type jsonlRowWriter struct {
+	enc *json.Encoder
+	buf *bufio.Writer
+	out io.Writer
+}
+
+// Write encodes the row on its own line; json.Encoder terminates each value with a newline
+func (w *jsonlRowWriter) Write(row Row) error {
+	return w.enc.Encode(row)
+}
+
+func (w *jsonlRowWriter) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	flushHTTP(w.out)
+	return nil
+}
+
+// flushHTTP sends the bytes written so far as a chunk when w is an HTTP response
+func flushHTTP(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,166 @@
+package failover
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin failover routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	failover := r.Group("/failover")
+	{
+		failover.GET("", h.Status)
+		failover.POST("/read-only", h.SetReadOnly)
+		failover.POST("/promote", h.Promote)
+	}
+}
+
+// ReadOnly rejects the requests changing data while the region refuses writes, with a
+// structured error and a Retry-After header, instead of letting them fail on a
+// read-only database. Reads and the exempt path prefixes, such as the failover admin
+// routes, always go through.
+func (h *Handler) ReadOnly(exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		block := h.service.WriteBlock()
+		if block == nil {
+			c.Next()
+			return
+		}
+
+		retryAfter := int(block.RetryAfter.Seconds())
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(block.Status, response.ErrorResponse{
+			Error: response.ErrorDetail{
+				Code:    block.Code,
+				Message: block.Message,
+				Details: map[string]interface{}{"retry_after_seconds": retryAfter},
+			},
+		})
+	}
+}
+
+// Status returns the failover status of the region
+// @Summary Get failover status
+// @Description Get the role of the region, whether it accepts writes, the replication lag of its database and the last promotion
+// @Tags failover
+// @Produce json
+// @Success 200 {object} response.Response{data=Status} "Failover status"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/failover [get]
+func (h *Handler) Status(c *gin.Context) {
+	status, err := h.service.Status(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, status)
+}
+
+// SetReadOnly turns writes off or back on in the region
+// @Summary Set read-only mode
+// @Description Turn writes off or back on in every instance of the region. While read-only, the requests changing data get 503 READ_ONLY with a Retry-After header. Set the old primary read-only before promoting the standby.
+// @Tags failover
+// @Accept json
+// @Produce json
+// @Param request body SetReadOnlyRequest true "Read-only mode"
+// @Success 200 {object} response.Response{data=Status} "Failover status"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/failover/read-only [post]
+func (h *Handler) SetReadOnly(c *gin.Context) {
+	var req SetReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	status, err := h.service.SetReadOnly(c.Request.Context(), req, adminID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, status)
+}
+
+// Promote promotes the standby of the region to primary
+// @Summary Promote region
+// @Description Promote the standby database of the region to primary. Writes are paused with 425 PROMOTION_IN_PROGRESS while the database is promoted, then accepted unless keepReadOnly is set. Refused when the standby is further behind than the maximum lag, unless forced.
+// @Tags failover
+// @Accept json
+// @Produce json
+// @Param request body PromoteRequest true "Promotion"
+// @Success 200 {object} response.Response{data=Status} "Region promoted"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 409 {object} response.ErrorResponse "Not a standby, too far behind or promotion in progress"
+// @Failure 500 {object} response.ErrorResponse "Promotion failed"
+// @Security BearerAuth
+// @Router /admin/failover/promote [post]
+func (h *Handler) Promote(c *gin.Context) {
+	var req PromoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	status, err := h.service.Promote(c.Request.Context(), req, adminID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, status)
+}
+
+func adminID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotStandby):
+		response.Conflict(c, "NOT_STANDBY", err.Error())
+	case errors.Is(err, ErrReplicationLag):
+		response.Conflict(c, "REPLICATION_LAG", err.Error())
+	case errors.Is(err, ErrPromotionInProgress):
+		response.Conflict(c, "PROMOTION_IN_PROGRESS", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
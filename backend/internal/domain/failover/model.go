@@ -0,0 +1,117 @@
+package failover
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StateKey is the Redis key of the failover state of the region. Redis keys are
+// namespaced per region, so that each region keeps its own state; the state is not
+// kept in the database, as the database of a standby cannot be written.
+const StateKey = "failover:state"
+
+// PromotionLockKey is held by the instance promoting the region
+const PromotionLockKey = "failover:promotion"
+
+// ReloadChannel is the Redis pub/sub channel state changes are broadcast on, so that
+// every API instance of the region applies them at once
+const ReloadChannel = "failover:reload"
+
+// Failover errors
+var (
+	ErrNotStandby          = errors.New("the database of this region is not a standby")
+	ErrReplicationLag      = errors.New("the standby is too far behind the primary, promote with force to accept the data loss")
+	ErrPromotionInProgress = errors.New("a promotion of this region is in progress")
+	ErrPromotionFailed     = errors.New("promotion failed")
+)
+
+// Role is the part a region plays: a primary accepts writes, a warm standby replays
+// the writes of the primary and only serves reads
+type Role string
+
+const (
+	RolePrimary Role = "PRIMARY"
+	RoleStandby Role = "STANDBY"
+)
+
+// WriteMode is whether the instances of the region accept writes
+type WriteMode string
+
+const (
+	WritesAccepted  WriteMode = "ACCEPTED"
+	WritesReadOnly  WriteMode = "READ_ONLY" // Turned off by an admin, e.g. to fence the old primary
+	WritesStandby   WriteMode = "STANDBY"   // The database is a standby
+	WritesPromoting WriteMode = "PROMOTING" // Paused while the standby is promoted
+)
+
+// WriteBlock is why the instances of the region refuse the requests changing data
+type WriteBlock struct {
+	Status     int // 503 while read-only or standby, 425 while the region is being promoted
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+// State is the failover state of the region shared by its instances through Redis
+type State struct {
+	ReadOnly       bool       `json:"readOnly"`
+	ReadOnlyReason string     `json:"readOnlyReason,omitempty"`
+	ReadOnlySince  *time.Time `json:"readOnlySince,omitempty"`
+	ReadOnlyBy     *uuid.UUID `json:"readOnlyBy,omitempty"`
+	Promoting      bool       `json:"promoting"`
+	LastPromotion  *Promotion `json:"lastPromotion,omitempty"`
+}
+
+// Promotion is a promotion of the standby of the region to primary, with the steps it
+// went through
+type Promotion struct {
+	Reason      string          `json:"reason"`
+	Forced      bool            `json:"forced"` // Promoted although the standby was too far behind
+	LagSeconds  float64         `json:"lagSeconds"`
+	By          *uuid.UUID      `json:"by,omitempty"`
+	StartedAt   time.Time       `json:"startedAt"`
+	CompletedAt *time.Time      `json:"completedAt,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Steps       []PromotionStep `json:"steps"`
+}
+
+// PromotionStep is a step of a promotion
+type PromotionStep struct {
+	Name    string    `json:"name"`
+	Message string    `json:"message,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Replication is the replication state of the database of the region
+type Replication struct {
+	InRecovery bool    `json:"inRecovery"` // The database is a standby
+	LagSeconds float64 `json:"lagSeconds"` // Standby: behind the primary; primary: the slowest standby
+	Standbys   int     `json:"standbys"`   // Primary: standbys streaming from it
+	Error      string  `json:"error,omitempty"`
+}
+
+// Status is the failover status of the region
+type Status struct {
+	Region        string      `json:"region"`
+	Role          Role        `json:"role"`
+	Writes        WriteMode   `json:"writes"`
+	Replication   Replication `json:"replication"`
+	MaxLagSeconds float64     `json:"maxLagSeconds"` // Above it promotion needs force
+	State         State       `json:"state"`
+	CheckedAt     time.Time   `json:"checkedAt"`
+}
+
+// SetReadOnlyRequest turns writes off or back on in the region
+type SetReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason" binding:"max=500"`
+}
+
+// PromoteRequest promotes the standby of the region to primary
+type PromoteRequest struct {
+	Reason       string `json:"reason" binding:"required,max=500"`
+	Force        bool   `json:"force"`        // Promote even when the standby is too far behind
+	KeepReadOnly bool   `json:"keepReadOnly"` // Keep refusing writes after promotion, e.g. to check the data first
+}
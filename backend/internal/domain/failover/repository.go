@@ -0,0 +1,34 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Promote(ctx context.Context, wait time.Duration) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Promote promotes the standby database to primary, waiting until it accepts writes
+func (r *repository) Promote(ctx context.Context, wait time.Duration) error {
+	var promoted bool
+	err := r.db.WithContext(ctx).Raw("SELECT pg_promote(true, ?)", int(wait.Seconds())).Scan(&promoted).Error
+	if err != nil {
+		return fmt.Errorf("failed to promote database: %w", err)
+	}
+	if !promoted {
+		return fmt.Errorf("database not promoted within %s", wait)
+	}
+	return nil
+}
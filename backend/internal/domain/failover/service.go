@@ -0,0 +1,468 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// refreshInterval is how often every instance checks the role of its database and
+// reloads the state, in case a broadcast was missed
+const refreshInterval = 5 * time.Second
+
+// ReplicationSource reports the replication state of the database; satisfied by
+// monitoring.ReplicationChecker
+type ReplicationSource interface {
+	Status(ctx context.Context) (monitoring.ReplicationStatus, error)
+}
+
+// AuditLogger records read-only changes and promotions; satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Config configures the failover of a region
+type Config struct {
+	Region      string        // e.g. eu-west; empty when running a single region
+	MaxLag      time.Duration // Above it promotion needs force
+	PromoteWait time.Duration // How long promotion waits for the database to accept writes
+}
+
+// DefaultConfig returns the failover defaults
+func DefaultConfig() Config {
+	return Config{
+		MaxLag:      30 * time.Second,
+		PromoteWait: 60 * time.Second,
+	}
+}
+
+// Service runs a region as the primary or the warm standby of the platform. The role
+// of the region follows its database: a database replaying the WAL of another region
+// is a standby. Writes are refused while the region is a standby, read-only or being
+// promoted, and admins promote the standby once the primary is lost.
+type Service struct {
+	repo        Repository
+	replication ReplicationSource
+	config      Config
+	redis       *redis.Client
+	audit       AuditLogger
+	now         func() time.Time
+
+	mu          sync.RWMutex
+	role        Role // Empty until the database has been checked
+	replicaInfo Replication
+	state       State
+	checkedAt   time.Time
+}
+
+// NewService creates the failover service of the region
+func NewService(repo Repository, replication ReplicationSource, config Config) *Service {
+	return &Service{
+		repo:        repo,
+		replication: replication,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// SetBroadcaster shares the state with the other instances of the region through
+// Redis. Without it the state only applies to this instance.
+func (s *Service) SetBroadcaster(client *redis.Client) {
+	s.redis = client
+}
+
+// SetAuditLogger records read-only changes and promotions in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// Load checks the role of the database and loads the shared state
+func (s *Service) Load(ctx context.Context) error {
+	s.refreshRole(ctx)
+
+	state, err := s.loadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+	return nil
+}
+
+// Listen applies the state changes of the other instances at once and checks the role
+// of the database every few seconds, until ctx is done
+func (s *Service) Listen(ctx context.Context) error {
+	if s.redis == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	pubsub := s.redis.Subscribe(ctx, ReloadChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to failover channel: %w", err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+			case <-ticker.C:
+			}
+			if err := s.Load(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to reload failover state")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// WriteBlock returns why writes are refused, nil when they are accepted. Writes are
+// accepted while the role of the database is unknown: they fail on their own if it
+// cannot be written.
+func (s *Service) WriteBlock() *WriteBlock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch s.writeMode() {
+	case WritesPromoting:
+		return &WriteBlock{
+			Status:     http.StatusTooEarly,
+			Code:       "PROMOTION_IN_PROGRESS",
+			Message:    "This region is becoming the primary region, retry in a few seconds",
+			RetryAfter: 10 * time.Second,
+		}
+	case WritesStandby:
+		return &WriteBlock{
+			Status:     http.StatusServiceUnavailable,
+			Code:       "REGION_STANDBY",
+			Message:    "This region is a standby and only serves reads",
+			RetryAfter: 60 * time.Second,
+		}
+	case WritesReadOnly:
+		return &WriteBlock{
+			Status:     http.StatusServiceUnavailable,
+			Code:       "READ_ONLY",
+			Message:    "Changes are temporarily disabled, retry later",
+			RetryAfter: 60 * time.Second,
+		}
+	default:
+		return nil
+	}
+}
+
+// writeMode must be called with the lock held
+func (s *Service) writeMode() WriteMode {
+	switch {
+	case s.state.Promoting:
+		return WritesPromoting
+	case s.role == RoleStandby:
+		return WritesStandby
+	case s.state.ReadOnly:
+		return WritesReadOnly
+	default:
+		return WritesAccepted
+	}
+}
+
+// Status checks the database and returns the failover status of the region
+func (s *Service) Status(ctx context.Context) (*Status, error) {
+	if err := s.Load(ctx); err != nil {
+		return nil, err
+	}
+	return s.status(), nil
+}
+
+// SetReadOnly turns writes off or back on in every instance of the region, e.g. to
+// fence the old primary before promoting the standby
+func (s *Service) SetReadOnly(ctx context.Context, req SetReadOnlyRequest, adminID *uuid.UUID) (*Status, error) {
+	enabled := req.Enabled != nil && *req.Enabled
+	err := s.update(ctx, func(state *State) error {
+		state.ReadOnly = enabled
+		state.ReadOnlyReason = ""
+		state.ReadOnlySince = nil
+		state.ReadOnlyBy = nil
+		if enabled {
+			now := s.now()
+			state.ReadOnlyReason = strings.TrimSpace(req.Reason)
+			state.ReadOnlySince = &now
+			state.ReadOnlyBy = adminID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx, adminID, "read_only", map[string]interface{}{
+		"enabled": enabled,
+		"reason":  req.Reason,
+	})
+	return s.status(), nil
+}
+
+// Promote promotes the standby database of the region to primary. Writes are paused
+// on every instance of the region while the database is promoted, then accepted
+// unless KeepReadOnly is set. The old primary must be fenced first if it is still
+// reachable, by setting it read-only.
+func (s *Service) Promote(ctx context.Context, req PromoteRequest, adminID *uuid.UUID) (*Status, error) {
+	replication, err := s.replication.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !replication.InRecovery {
+		return nil, ErrNotStandby
+	}
+	if replication.Lag > s.config.MaxLag && !req.Force {
+		return nil, ErrReplicationLag
+	}
+
+	unlock, err := s.lockPromotion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	promotion := &Promotion{
+		Reason:     strings.TrimSpace(req.Reason),
+		Forced:     replication.Lag > s.config.MaxLag,
+		LagSeconds: replication.Lag.Seconds(),
+		By:         adminID,
+		StartedAt:  s.now(),
+	}
+	promotion.step("replication_checked", fmt.Sprintf("standby %s behind the primary", replication.Lag.Round(time.Millisecond)), s.now())
+
+	err = s.update(ctx, func(state *State) error {
+		if state.Promoting {
+			return ErrPromotionInProgress
+		}
+		state.Promoting = true
+		state.LastPromotion = promotion
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	promotion.step("writes_paused", "", s.now())
+
+	if err := s.promote(ctx); err != nil {
+		promotion.Error = err.Error()
+		if saveErr := s.update(ctx, func(state *State) error {
+			state.Promoting = false
+			state.LastPromotion = promotion
+			return nil
+		}); saveErr != nil {
+			log.Error().Err(saveErr).Msg("Failed to record failed promotion")
+		}
+		log.Error().Err(err).Str("region", s.config.Region).Msg("Promotion failed")
+		return nil, fmt.Errorf("%w: %v", ErrPromotionFailed, err)
+	}
+	promotion.step("database_promoted", "", s.now())
+
+	completedAt := s.now()
+	promotion.CompletedAt = &completedAt
+	if req.KeepReadOnly {
+		promotion.step("writes_kept_off", "", completedAt)
+	} else {
+		promotion.step("writes_accepted", "", completedAt)
+	}
+	err = s.update(ctx, func(state *State) error {
+		state.Promoting = false
+		state.LastPromotion = promotion
+		if !req.KeepReadOnly {
+			state.ReadOnly = false
+			state.ReadOnlyReason = ""
+			state.ReadOnlySince = nil
+			state.ReadOnlyBy = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Warn().Str("region", s.config.Region).Bool("forced", promotion.Forced).Msg("Region promoted to primary")
+	s.log(ctx, adminID, "promote", map[string]interface{}{
+		"reason":       promotion.Reason,
+		"forced":       promotion.Forced,
+		"lagSeconds":   promotion.LagSeconds,
+		"keepReadOnly": req.KeepReadOnly,
+	})
+	return s.status(), nil
+}
+
+// promote promotes the database and checks that it left recovery
+func (s *Service) promote(ctx context.Context) error {
+	if err := s.repo.Promote(ctx, s.config.PromoteWait); err != nil {
+		return err
+	}
+
+	replication, err := s.replication.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if replication.InRecovery {
+		return errors.New("database still in recovery after promotion")
+	}
+
+	s.refreshRole(ctx)
+	return nil
+}
+
+// lockPromotion makes sure a single instance promotes the region
+func (s *Service) lockPromotion(ctx context.Context) (func(), error) {
+	if s.redis == nil {
+		return func() {}, nil
+	}
+
+	ttl := s.config.PromoteWait + time.Minute
+	locked, err := s.redis.SetNX(ctx, PromotionLockKey, s.now().Format(time.RFC3339), ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock promotion: %w", err)
+	}
+	if !locked {
+		return nil, ErrPromotionInProgress
+	}
+
+	return func() {
+		if err := s.redis.Del(context.Background(), PromotionLockKey).Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to release promotion lock")
+		}
+	}, nil
+}
+
+// refreshRole checks whether the database is a standby. The role is kept when the
+// check fails, as the database checker reports the outage.
+func (s *Service) refreshRole(ctx context.Context) {
+	replication, err := s.replication.Status(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkedAt = s.now()
+	if err != nil {
+		s.replicaInfo.Error = err.Error()
+		return
+	}
+
+	s.replicaInfo = Replication{
+		InRecovery: replication.InRecovery,
+		LagSeconds: replication.Lag.Seconds(),
+		Standbys:   replication.Standbys,
+	}
+	role := RolePrimary
+	if replication.InRecovery {
+		role = RoleStandby
+	}
+	if s.role != "" && s.role != role {
+		log.Warn().Str("region", s.config.Region).Str("role", string(role)).Msg("Database role changed")
+	}
+	s.role = role
+}
+
+// update applies a change to the latest shared state, saves it and tells the other
+// instances of the region
+func (s *Service) update(ctx context.Context, change func(state *State) error) error {
+	state, err := s.loadState(ctx)
+	if err != nil {
+		return err
+	}
+	if err := change(&state); err != nil {
+		return err
+	}
+
+	if s.redis != nil {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("failed to encode failover state: %w", err)
+		}
+		if err := s.redis.Set(ctx, StateKey, data, 0).Err(); err != nil {
+			return fmt.Errorf("failed to save failover state: %w", err)
+		}
+		if err := s.redis.Publish(ctx, ReloadChannel, "state").Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to broadcast failover state change")
+		}
+	}
+
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+	return nil
+}
+
+// loadState reads the shared state, or the state of this instance without Redis
+func (s *Service) loadState(ctx context.Context) (State, error) {
+	if s.redis == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.state, nil
+	}
+
+	var state State
+	data, err := s.redis.Get(ctx, StateKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to load failover state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to decode failover state: %w", err)
+	}
+	return state, nil
+}
+
+func (s *Service) status() *Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Status{
+		Region:        s.config.Region,
+		Role:          s.role,
+		Writes:        s.writeMode(),
+		Replication:   s.replicaInfo,
+		MaxLagSeconds: s.config.MaxLag.Seconds(),
+		State:         s.state,
+		CheckedAt:     s.checkedAt,
+	}
+}
+
+func (s *Service) log(ctx context.Context, adminID *uuid.UUID, op string, metadata map[string]interface{}) {
+	if s.audit == nil {
+		return
+	}
+	metadata["op"] = op
+	s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+		UserID:     adminID,
+		Action:     audit.ActionSettingsUpdate,
+		Resource:   "failover",
+		ResourceID: s.config.Region,
+		Metadata:   metadata,
+	})
+}
+
+func (p *Promotion) step(name, message string, at time.Time) {
+	p.Steps = append(p.Steps, PromotionStep{Name: name, Message: message, At: at})
+}
@@ -0,0 +1,108 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReplication reports the replication status of the database of the region
+type fakeReplication struct {
+	status monitoring.ReplicationStatus
+}
+
+func (r *fakeReplication) Status(ctx context.Context) (monitoring.ReplicationStatus, error) {
+	return r.status, nil
+}
+
+func newTestService(status monitoring.ReplicationStatus) (*Service, *MockRepository, *fakeReplication) {
+	mockRepo := NewMockRepository()
+	replication := &fakeReplication{status: status}
+	config := DefaultConfig()
+	config.Region = "eu-west"
+	service := NewService(mockRepo, replication, config)
+	service.now = func() time.Time { return time.Date(2026, 7, 18, 14, 0, 0, 0, time.UTC) }
+	return service, mockRepo, replication
+}
+
+func enabled(v bool) *bool {
+	return &v
+}
+
+func TestService_WriteBlock(t *testing.T) {
+	ctx := context.Background()
+
+	service, _, _ := newTestService(monitoring.ReplicationStatus{Standbys: 1})
+	assert.Nil(t, service.WriteBlock(), "writes accepted before the role is known")
+	require.NoError(t, service.Load(ctx))
+	assert.Nil(t, service.WriteBlock())
+
+	status, err := service.SetReadOnly(ctx, SetReadOnlyRequest{Enabled: enabled(true), Reason: " fencing "}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, WritesReadOnly, status.Writes)
+	assert.Equal(t, "fencing", status.State.ReadOnlyReason)
+	block := service.WriteBlock()
+	require.NotNil(t, block)
+	assert.Equal(t, http.StatusServiceUnavailable, block.Status)
+	assert.Equal(t, "READ_ONLY", block.Code)
+
+	_, err = service.SetReadOnly(ctx, SetReadOnlyRequest{Enabled: enabled(false)}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, service.WriteBlock())
+
+	standby, _, _ := newTestService(monitoring.ReplicationStatus{InRecovery: true})
+	require.NoError(t, standby.Load(ctx))
+	block = standby.WriteBlock()
+	require.NotNil(t, block)
+	assert.Equal(t, "REGION_STANDBY", block.Code)
+}
+
+func TestService_Promote(t *testing.T) {
+	ctx := context.Background()
+
+	primary, _, _ := newTestService(monitoring.ReplicationStatus{Standbys: 1})
+	_, err := primary.Promote(ctx, PromoteRequest{Reason: "drill"}, nil)
+	assert.ErrorIs(t, err, ErrNotStandby)
+
+	service, mockRepo, replication := newTestService(monitoring.ReplicationStatus{InRecovery: true, Lag: 2 * time.Minute})
+	require.NoError(t, service.Load(ctx))
+	_, err = service.Promote(ctx, PromoteRequest{Reason: "primary lost"}, nil)
+	assert.ErrorIs(t, err, ErrReplicationLag)
+	mockRepo.AssertNotCalled(t, "Promote", mock.Anything, mock.Anything)
+
+	wait := DefaultConfig().PromoteWait
+	mockRepo.On("Promote", mock.Anything, wait).Return(errors.New("timeout")).Once()
+	_, err = service.Promote(ctx, PromoteRequest{Reason: "primary lost", Force: true}, nil)
+	assert.ErrorIs(t, err, ErrPromotionFailed)
+	status, err := service.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, RoleStandby, status.Role)
+	assert.False(t, status.State.Promoting, "writes no longer paused after a failed promotion")
+	require.NotNil(t, status.State.LastPromotion)
+	assert.Contains(t, status.State.LastPromotion.Error, "timeout")
+
+	mockRepo.On("Promote", mock.Anything, wait).
+		Run(func(args mock.Arguments) { replication.status = monitoring.ReplicationStatus{} }).
+		Return(nil).Once()
+	_, err = service.SetReadOnly(ctx, SetReadOnlyRequest{Enabled: enabled(true)}, nil)
+	require.NoError(t, err)
+	status, err = service.Promote(ctx, PromoteRequest{Reason: "primary lost", Force: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, RolePrimary, status.Role)
+	assert.Equal(t, WritesAccepted, status.Writes, "promotion turns writes back on")
+	promotion := status.State.LastPromotion
+	require.NotNil(t, promotion)
+	assert.True(t, promotion.Forced)
+	assert.NotNil(t, promotion.CompletedAt)
+	assert.Empty(t, promotion.Error)
+	assert.Len(t, promotion.Steps, 4)
+	assert.Nil(t, service.WriteBlock())
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,312 @@
+package feedback
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers festival-scoped feedback routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	// Attendee feedback
+	r.GET("/me/feedback/prompts", h.Prompts)
+	r.POST("/me/feedback", h.Submit)
+
+	feedback := r.Group("/feedback")
+	{
+		// Public stand comments and ratings
+		feedback.GET("/stands/:standId", h.ListStandFeedback)
+
+		// Organizer moderation
+		feedback.GET("", h.List)
+		feedback.GET("/ratings", h.Ratings)
+		feedback.PATCH("/:feedbackId", h.Moderate)
+	}
+}
+
+// Prompts lists the recent orders the attendee is invited to rate
+// @Summary List feedback prompts
+// @Description Get the paid orders of the last 24 hours that the attendee has not rated yet
+// @Tags feedback
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]RateableOrder} "Orders to rate"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/feedback/prompts [get]
+func (h *Handler) Prompts(c *gin.Context) {
+	festivalID, userID, ok := h.festivalAndUser(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.service.Prompts(c.Request.Context(), festivalID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, orders)
+}
+
+// Submit rates an order
+// @Summary Submit feedback
+// @Description Rate a paid order from 1 to 5 with an optional comment; flagged comments are held for review
+// @Tags feedback
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body SubmitFeedbackRequest true "Feedback"
+// @Success 201 {object} response.Response{data=FeedbackResponse} "Feedback submitted"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or order cannot be rated"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Feedback already submitted"
+// @Failure 429 {object} response.ErrorResponse "Too many submissions"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/feedback [post]
+func (h *Handler) Submit(c *gin.Context) {
+	festivalID, userID, ok := h.festivalAndUser(c)
+	if !ok {
+		return
+	}
+
+	var req SubmitFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	feedback, err := h.service.Submit(c.Request.Context(), festivalID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, feedback.ToResponse())
+}
+
+// ListStandFeedback lists the published comments of a stand with its rating breakdown
+// @Summary List stand feedback
+// @Description Get the rating breakdown and published comments of a stand
+// @Tags feedback
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]PublicFeedbackResponse} "Stand feedback"
+// @Failure 400 {object} response.ErrorResponse "Invalid stand ID"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/feedback/stands/{standId} [get]
+func (h *Handler) ListStandFeedback(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	standID, err := uuid.Parse(c.Param("standId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	feedbacks, total, err := h.service.ListPublished(c.Request.Context(), standID, page, perPage)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	summaries, err := h.service.GetRatingSummaries(c.Request.Context(), festivalID, []uuid.UUID{standID})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]PublicFeedbackResponse, len(feedbacks))
+	for i := range feedbacks {
+		items[i] = feedbacks[i].ToPublicResponse()
+	}
+
+	result := gin.H{"comments": items, "rating": nil}
+	if len(summaries) > 0 {
+		result["rating"] = summaries[0]
+	}
+
+	response.OKWithMeta(c, result, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// List lists the feedback of the festival for moderation
+// @Summary List feedback
+// @Description Get the feedback of the festival, optionally filtered by stand and moderation status
+// @Tags feedback
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Stand ID" format(uuid)
+// @Param status query string false "Moderation status" Enums(PUBLISHED, PENDING_REVIEW, HIDDEN)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]FeedbackResponse} "Feedback"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/feedback [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query ListFeedbackQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	feedbacks, total, err := h.service.List(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]FeedbackResponse, len(feedbacks))
+	for i := range feedbacks {
+		items[i] = feedbacks[i].ToResponse()
+	}
+
+	response.OKWithMeta(c, items, &response.Meta{
+		Total:   int(total),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// Ratings returns the rating breakdown of every stand of the festival
+// @Summary Get stand ratings
+// @Description Get the average rating and star distribution per stand; hidden feedback is excluded
+// @Tags feedback
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]StandRatingSummary} "Stand ratings"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/feedback/ratings [get]
+func (h *Handler) Ratings(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	summaries, err := h.service.GetRatingSummaries(c.Request.Context(), festivalID, nil)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, summaries)
+}
+
+// Moderate changes the moderation status of a feedback
+// @Summary Moderate feedback
+// @Description Publish, hold or hide a feedback; hidden feedback no longer counts in ratings
+// @Tags feedback
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param feedbackId path string true "Feedback ID" format(uuid)
+// @Param request body ModerateFeedbackRequest true "New status"
+// @Success 200 {object} response.Response{data=FeedbackResponse} "Feedback updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Feedback not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/feedback/{feedbackId} [patch]
+func (h *Handler) Moderate(c *gin.Context) {
+	festivalID, moderatorID, ok := h.festivalAndUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("feedbackId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid feedback ID", nil)
+		return
+	}
+
+	var req ModerateFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	feedback, err := h.service.Moderate(c.Request.Context(), festivalID, id, moderatorID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, feedback.ToResponse())
+}
+
+func (h *Handler) festivalAndUser(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return festivalID, userID, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFeedbackNotFound):
+		response.NotFound(c, "Feedback not found")
+	case errors.Is(err, ErrOrderNotFound):
+		response.NotFound(c, "Order not found")
+	case errors.Is(err, ErrAlreadySubmitted):
+		response.Conflict(c, "FEEDBACK_EXISTS", err.Error())
+	case errors.Is(err, ErrOrderNotRateable),
+		errors.Is(err, ErrFeedbackWindowOver):
+		response.BadRequest(c, "ORDER_NOT_RATEABLE", err.Error(), nil)
+	case errors.Is(err, ErrTooManySubmissions):
+		response.TooManyRequests(c, int(time.Hour.Seconds()))
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,148 @@
+package feedback
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PromptWindow is how long after a purchase attendees are prompted for feedback
+	PromptWindow = 24 * time.Hour
+	// MaxCommentLength is the maximum length of a feedback comment
+	MaxCommentLength = 1000
+	// MaxSubmissionsPerHour limits how much feedback one attendee can post
+	MaxSubmissionsPerHour = 10
+)
+
+// Feedback errors
+var (
+	ErrFeedbackNotFound   = errors.New("feedback not found")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderNotRateable   = errors.New("only paid orders can be rated")
+	ErrFeedbackWindowOver = errors.New("feedback window for this order is over")
+	ErrAlreadySubmitted   = errors.New("feedback already submitted for this order")
+	ErrTooManySubmissions = errors.New("too many feedback submissions, try again later")
+)
+
+// Status is the moderation status of a feedback
+type Status string
+
+const (
+	StatusPublished     Status = "PUBLISHED"      // Counted in ratings, comment visible
+	StatusPendingReview Status = "PENDING_REVIEW" // Counted in ratings, comment held for moderation
+	StatusHidden        Status = "HIDDEN"         // Excluded from ratings and hidden
+)
+
+// Feedback is an attendee rating of a stand for one order
+type Feedback struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID     uuid.UUID  `json:"standId" gorm:"type:uuid;not null;index"`
+	OrderID     uuid.UUID  `json:"orderId" gorm:"type:uuid;not null;uniqueIndex"`
+	UserID      uuid.UUID  `json:"userId" gorm:"type:uuid;not null;index"`
+	Rating      int        `json:"rating" gorm:"not null"`
+	Comment     string     `json:"comment,omitempty"`
+	Status      Status     `json:"status" gorm:"default:'PUBLISHED'"`
+	FlagReason  string     `json:"flagReason,omitempty"`
+	ModeratedBy *uuid.UUID `json:"moderatedBy,omitempty" gorm:"type:uuid"`
+	ModeratedAt *time.Time `json:"moderatedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Feedback) TableName() string {
+	return "stand_feedback"
+}
+
+// RateableOrder is a paid order of an attendee that has no feedback yet
+type RateableOrder struct {
+	OrderID     uuid.UUID `json:"orderId" gorm:"column:order_id"`
+	StandID     uuid.UUID `json:"standId" gorm:"column:stand_id"`
+	StandName   string    `json:"standName" gorm:"column:stand_name"`
+	TotalAmount int64     `json:"totalAmount" gorm:"column:total_amount"`
+	OrderedAt   time.Time `json:"orderedAt" gorm:"column:created_at"`
+}
+
+// OrderRef holds the order fields needed to validate a feedback
+type OrderRef struct {
+	ID         uuid.UUID `gorm:"column:id"`
+	FestivalID uuid.UUID `gorm:"column:festival_id"`
+	UserID     uuid.UUID `gorm:"column:user_id"`
+	StandID    uuid.UUID `gorm:"column:stand_id"`
+	Status     string    `gorm:"column:status"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// StandRatingSummary aggregates the counted feedback of a stand
+type StandRatingSummary struct {
+	StandID   uuid.UUID `json:"standId" gorm:"column:stand_id"`
+	Average   float64   `json:"average" gorm:"column:average"`
+	Count     int       `json:"count" gorm:"column:count"`
+	OneStar   int       `json:"oneStar" gorm:"column:one_star"`
+	TwoStar   int       `json:"twoStar" gorm:"column:two_star"`
+	ThreeStar int       `json:"threeStar" gorm:"column:three_star"`
+	FourStar  int       `json:"fourStar" gorm:"column:four_star"`
+	FiveStar  int       `json:"fiveStar" gorm:"column:five_star"`
+}
+
+// SubmitFeedbackRequest represents the request to rate an order
+type SubmitFeedbackRequest struct {
+	OrderID uuid.UUID `json:"orderId" binding:"required"`
+	Rating  int       `json:"rating" binding:"required,min=1,max=5"`
+	Comment string    `json:"comment" binding:"max=1000"`
+}
+
+// ModerateFeedbackRequest represents the request to change the status of a feedback
+type ModerateFeedbackRequest struct {
+	Status Status `json:"status" binding:"required,oneof=PUBLISHED PENDING_REVIEW HIDDEN"`
+}
+
+// ListFeedbackQuery filters the feedback of a festival
+type ListFeedbackQuery struct {
+	StandID *uuid.UUID `form:"standId"`
+	Status  Status     `form:"status" binding:"omitempty,oneof=PUBLISHED PENDING_REVIEW HIDDEN"`
+	Page    int        `form:"page,default=1" binding:"min=1"`
+	PerPage int        `form:"per_page,default=20" binding:"min=1,max=100"`
+}
+
+// FeedbackResponse represents the organizer-facing API response for a feedback
+type FeedbackResponse struct {
+	ID         uuid.UUID `json:"id"`
+	StandID    uuid.UUID `json:"standId"`
+	OrderID    uuid.UUID `json:"orderId"`
+	Rating     int       `json:"rating"`
+	Comment    string    `json:"comment,omitempty"`
+	Status     Status    `json:"status"`
+	FlagReason string    `json:"flagReason,omitempty"`
+	CreatedAt  string    `json:"createdAt"`
+}
+
+func (f *Feedback) ToResponse() FeedbackResponse {
+	return FeedbackResponse{
+		ID:         f.ID,
+		StandID:    f.StandID,
+		OrderID:    f.OrderID,
+		Rating:     f.Rating,
+		Comment:    f.Comment,
+		Status:     f.Status,
+		FlagReason: f.FlagReason,
+		CreatedAt:  f.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// PublicFeedbackResponse is a published feedback shown on the stand page, without attendee data
+type PublicFeedbackResponse struct {
+	Rating    int    `json:"rating"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+func (f *Feedback) ToPublicResponse() PublicFeedbackResponse {
+	return PublicFeedbackResponse{
+		Rating:    f.Rating,
+		Comment:   f.Comment,
+		CreatedAt: f.CreatedAt.Format(time.RFC3339),
+	}
+}
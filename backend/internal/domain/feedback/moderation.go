@@ -0,0 +1,150 @@
+package feedback
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Flag reasons set by the automatic screening
+const (
+	FlagOffensive = "offensive language"
+	FlagContact   = "links or contact details"
+	FlagSpam      = "repetitive content"
+)
+
+var (
+	linkPattern   = regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9-]+\.(com|net|org|io|be|fr|nl|de|eu)\b)`)
+	emailPattern  = regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)
+	phonePattern  = regexp.MustCompile(`\+?\d[\d .-]{7,}\d`)
+	whitespaceRun = regexp.MustCompile(`\s+`)
+	leetReplacer  = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+	// blockedWords only match whole words, blockedStems also match inside longer words
+	blockedWords = []string{
+		"bitch", "bastard", "dick", "whore", "slut", "retard",
+		"merde", "putain", "connard", "connasse", "salope", "encule", "pute",
+		"kut", "hoer", "lul", "tyfus",
+		"scheisse", "fotze", "wichser",
+	}
+	blockedStems = []string{"fuck", "shit", "cunt", "asshole", "klootzak", "arschloch", "hurensohn"}
+)
+
+// NormalizeComment trims a comment and collapses its whitespace
+func NormalizeComment(comment string) string {
+	return strings.TrimSpace(whitespaceRun.ReplaceAllString(comment, " "))
+}
+
+// Screen checks a comment for abuse and returns the status the feedback should get,
+// with the reason when it is held for review. Held comments still count in ratings
+// until an organizer hides them.
+func Screen(comment string) (Status, string) {
+	if comment == "" {
+		return StatusPublished, ""
+	}
+
+	if containsBlockedWord(comment) {
+		return StatusPendingReview, FlagOffensive
+	}
+	if linkPattern.MatchString(comment) || emailPattern.MatchString(comment) || phonePattern.MatchString(comment) {
+		return StatusPendingReview, FlagContact
+	}
+	if isRepetitive(comment) {
+		return StatusPendingReview, FlagSpam
+	}
+
+	return StatusPublished, ""
+}
+
+// containsBlockedWord matches blocked words against each word of the comment, after
+// undoing common obfuscations (accents, leetspeak, repeated letters)
+func containsBlockedWord(comment string) bool {
+	words := strings.FieldsFunc(foldText(comment), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	for _, word := range words {
+		squeezed := squeezeRepeats(word)
+		for _, blocked := range blockedWords {
+			if word == blocked || squeezed == blocked {
+				return true
+			}
+		}
+		for _, stem := range blockedStems {
+			if strings.Contains(word, stem) || strings.Contains(squeezed, stem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRepetitive reports comments made of one character or one word repeated over and over
+func isRepetitive(comment string) bool {
+	var last rune
+	run := 0
+	for _, r := range comment {
+		if r == last {
+			run++
+			if run >= 10 {
+				return true
+			}
+		} else {
+			last, run = r, 1
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(comment))
+	if len(words) < 6 {
+		return false
+	}
+	counts := make(map[string]int, len(words))
+	for _, w := range words {
+		counts[w]++
+		if counts[w]*2 > len(words) {
+			return true
+		}
+	}
+	return false
+}
+
+// foldText lowercases text, strips accents and replaces leetspeak digits and symbols
+func foldText(s string) string {
+	s = leetReplacer.Replace(strings.ToLower(s))
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch r {
+		case 'à', 'á', 'â', 'ä', 'ã':
+			r = 'a'
+		case 'é', 'è', 'ê', 'ë':
+			r = 'e'
+		case 'í', 'ì', 'î', 'ï':
+			r = 'i'
+		case 'ó', 'ò', 'ô', 'ö', 'õ':
+			r = 'o'
+		case 'ú', 'ù', 'û', 'ü':
+			r = 'u'
+		case 'ç':
+			r = 'c'
+		case 'ß':
+			b.WriteString("ss")
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// squeezeRepeats collapses runs of the same letter ("fuuuck" -> "fuck")
+func squeezeRepeats(word string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range word {
+		if r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package feedback
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScreen tests the automatic abuse screening of comments
+func TestScreen(t *testing.T) {
+	tests := []struct {
+		name       string
+		comment    string
+		wantStatus Status
+		wantReason string
+	}{
+		{
+			name:       "empty comment is published",
+			comment:    "",
+			wantStatus: StatusPublished,
+		},
+		{
+			name:       "regular comment is published",
+			comment:    "Great burgers, friendly staff but the queue was long",
+			wantStatus: StatusPublished,
+		},
+		{
+			name:       "blocked word inside a longer harmless word is published",
+			comment:    "Nice watering station and a cute dickens quote on the menu",
+			wantStatus: StatusPublished,
+		},
+		{
+			name:       "offensive word is held",
+			comment:    "The cashier was a bitch",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagOffensive,
+		},
+		{
+			name:       "obfuscated stem is held",
+			comment:    "F*U*U*C*K this st4nd, sh1tty fries",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagOffensive,
+		},
+		{
+			name:       "accented insult is held",
+			comment:    "Service de merde, enculé",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagOffensive,
+		},
+		{
+			name:       "link is held",
+			comment:    "Better food at www.example.com",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagContact,
+		},
+		{
+			name:       "email is held",
+			comment:    "Contact me at someone@example.org for cheap tickets",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagContact,
+		},
+		{
+			name:       "phone number is held",
+			comment:    "Call +32 470 12 34 56 for drinks",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagContact,
+		},
+		{
+			name:       "repeated characters are held",
+			comment:    "!!!!!!!!!!!!!!!",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagSpam,
+		},
+		{
+			name:       "repeated word is held",
+			comment:    "bad bad bad bad bad bad bad okay",
+			wantStatus: StatusPendingReview,
+			wantReason: FlagSpam,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reason := Screen(NormalizeComment(tt.comment))
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+// TestNormalizeComment tests whitespace normalization
+func TestNormalizeComment(t *testing.T) {
+	assert.Equal(t, "Nice beer", NormalizeComment("  Nice \t beer \n"))
+}
@@ -0,0 +1,173 @@
+package feedback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, feedback *Feedback) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Feedback, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*Feedback, error)
+	Update(ctx context.Context, feedback *Feedback) error
+	List(ctx context.Context, festivalID uuid.UUID, query ListFeedbackQuery) ([]Feedback, int64, error)
+	ListPublished(ctx context.Context, standID uuid.UUID, offset, limit int) ([]Feedback, int64, error)
+	CountByUserSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+	GetOrder(ctx context.Context, orderID uuid.UUID) (*OrderRef, error)
+	ListRateableOrders(ctx context.Context, festivalID, userID uuid.UUID, since time.Time) ([]RateableOrder, error)
+	GetRatingSummaries(ctx context.Context, festivalID uuid.UUID, standIDs []uuid.UUID) ([]StandRatingSummary, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, feedback *Feedback) error {
+	return r.db.WithContext(ctx).Create(feedback).Error
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Feedback, error) {
+	var feedback Feedback
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&feedback).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get feedback: %w", err)
+	}
+	return &feedback, nil
+}
+
+func (r *repository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*Feedback, error) {
+	var feedback Feedback
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&feedback).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get feedback by order: %w", err)
+	}
+	return &feedback, nil
+}
+
+func (r *repository) Update(ctx context.Context, feedback *Feedback) error {
+	return r.db.WithContext(ctx).Save(feedback).Error
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, query ListFeedbackQuery) ([]Feedback, int64, error) {
+	var feedbacks []Feedback
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&Feedback{}).Where("festival_id = ?", festivalID)
+	if query.StandID != nil {
+		q = q.Where("stand_id = ?", *query.StandID)
+	}
+	if query.Status != "" {
+		q = q.Where("status = ?", query.Status)
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count feedback: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	if err := q.Offset(offset).Limit(query.PerPage).Order("created_at DESC").Find(&feedbacks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list feedback: %w", err)
+	}
+
+	return feedbacks, total, nil
+}
+
+// ListPublished lists the published feedback of a stand that has a comment
+func (r *repository) ListPublished(ctx context.Context, standID uuid.UUID, offset, limit int) ([]Feedback, int64, error) {
+	var feedbacks []Feedback
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&Feedback{}).
+		Where("stand_id = ? AND status = ? AND comment <> ''", standID, StatusPublished)
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count feedback: %w", err)
+	}
+
+	if err := q.Offset(offset).Limit(limit).Order("created_at DESC").Find(&feedbacks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list feedback: %w", err)
+	}
+
+	return feedbacks, total, nil
+}
+
+func (r *repository) CountByUserSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Feedback{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count user feedback: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) GetOrder(ctx context.Context, orderID uuid.UUID) (*OrderRef, error) {
+	var order OrderRef
+	err := r.db.WithContext(ctx).Table("orders").
+		Select("id, festival_id, user_id, stand_id, status, created_at").
+		Where("id = ?", orderID).
+		Take(&order).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return &order, nil
+}
+
+// ListRateableOrders lists the paid orders of an attendee since the given time that have no feedback yet
+func (r *repository) ListRateableOrders(ctx context.Context, festivalID, userID uuid.UUID, since time.Time) ([]RateableOrder, error) {
+	var orders []RateableOrder
+	err := r.db.WithContext(ctx).Table("orders o").
+		Select("o.id as order_id, o.stand_id, s.name as stand_name, o.total_amount, o.created_at").
+		Joins("INNER JOIN stands s ON s.id = o.stand_id").
+		Joins("LEFT JOIN stand_feedback f ON f.order_id = o.id").
+		Where("o.festival_id = ? AND o.user_id = ? AND o.status = ? AND o.created_at >= ?", festivalID, userID, "PAID", since).
+		Where("f.id IS NULL").
+		Order("o.created_at DESC").
+		Scan(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rateable orders: %w", err)
+	}
+	return orders, nil
+}
+
+// GetRatingSummaries aggregates the feedback counted in ratings per stand; an empty
+// standIDs covers every stand of the festival
+func (r *repository) GetRatingSummaries(ctx context.Context, festivalID uuid.UUID, standIDs []uuid.UUID) ([]StandRatingSummary, error) {
+	q := r.db.WithContext(ctx).Model(&Feedback{}).
+		Select(`stand_id,
+			AVG(rating) as average,
+			COUNT(*) as count,
+			COUNT(*) FILTER (WHERE rating = 1) as one_star,
+			COUNT(*) FILTER (WHERE rating = 2) as two_star,
+			COUNT(*) FILTER (WHERE rating = 3) as three_star,
+			COUNT(*) FILTER (WHERE rating = 4) as four_star,
+			COUNT(*) FILTER (WHERE rating = 5) as five_star`).
+		Where("festival_id = ? AND status <> ?", festivalID, StatusHidden)
+	if len(standIDs) > 0 {
+		q = q.Where("stand_id IN ?", standIDs)
+	}
+
+	var summaries []StandRatingSummary
+	if err := q.Group("stand_id").Scan(&summaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get rating summaries: %w", err)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,153 @@
+package feedback
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Submit rates a paid order of the attendee. Comments are screened for abuse and
+// held for review when flagged.
+func (s *Service) Submit(ctx context.Context, festivalID, userID uuid.UUID, req SubmitFeedbackRequest) (*Feedback, error) {
+	order, err := s.repo.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.UserID != userID || order.FestivalID != festivalID {
+		return nil, ErrOrderNotFound
+	}
+	if order.Status != "PAID" {
+		return nil, ErrOrderNotRateable
+	}
+	if time.Since(order.CreatedAt) > PromptWindow {
+		return nil, ErrFeedbackWindowOver
+	}
+
+	existing, err := s.repo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadySubmitted
+	}
+
+	recent, err := s.repo.CountByUserSince(ctx, userID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if recent >= MaxSubmissionsPerHour {
+		return nil, ErrTooManySubmissions
+	}
+
+	comment := NormalizeComment(req.Comment)
+	status, reason := Screen(comment)
+
+	now := time.Now()
+	feedback := &Feedback{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    order.StandID,
+		OrderID:    order.ID,
+		UserID:     userID,
+		Rating:     req.Rating,
+		Comment:    comment,
+		Status:     status,
+		FlagReason: reason,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.Create(ctx, feedback); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// Prompts lists the recent orders the attendee can still rate
+func (s *Service) Prompts(ctx context.Context, festivalID, userID uuid.UUID) ([]RateableOrder, error) {
+	return s.repo.ListRateableOrders(ctx, festivalID, userID, time.Now().Add(-PromptWindow))
+}
+
+// List lists the feedback of a festival for moderation
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, query ListFeedbackQuery) ([]Feedback, int64, error) {
+	return s.repo.List(ctx, festivalID, query)
+}
+
+// ListPublished lists the published comments of a stand
+func (s *Service) ListPublished(ctx context.Context, standID uuid.UUID, page, perPage int) ([]Feedback, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return s.repo.ListPublished(ctx, standID, (page-1)*perPage, perPage)
+}
+
+// Moderate changes the status of a feedback
+func (s *Service) Moderate(ctx context.Context, festivalID, id, moderatorID uuid.UUID, req ModerateFeedbackRequest) (*Feedback, error) {
+	feedback, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if feedback == nil || feedback.FestivalID != festivalID {
+		return nil, ErrFeedbackNotFound
+	}
+
+	now := time.Now()
+	feedback.Status = req.Status
+	feedback.ModeratedBy = &moderatorID
+	feedback.ModeratedAt = &now
+	feedback.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, feedback); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// GetRatingSummaries returns the rating breakdown of the given stands, or of all stands
+func (s *Service) GetRatingSummaries(ctx context.Context, festivalID uuid.UUID, standIDs []uuid.UUID) ([]StandRatingSummary, error) {
+	summaries, err := s.repo.GetRatingSummaries(ctx, festivalID, standIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		summaries[i].Average = roundRating(summaries[i].Average)
+	}
+	return summaries, nil
+}
+
+// GetStandRatings returns the average rating per stand; satisfies stand.RatingProvider
+func (s *Service) GetStandRatings(ctx context.Context, festivalID uuid.UUID) (map[uuid.UUID]stand.Rating, error) {
+	summaries, err := s.repo.GetRatingSummaries(ctx, festivalID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[uuid.UUID]stand.Rating, len(summaries))
+	for _, summary := range summaries {
+		ratings[summary.StandID] = stand.Rating{
+			Average: roundRating(summary.Average),
+			Count:   summary.Count,
+		}
+	}
+	return ratings, nil
+}
+
+func roundRating(average float64) float64 {
+	return math.Round(average*10) / 10
+}
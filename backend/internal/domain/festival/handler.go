@@ -47,7 +47,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 func (h *Handler) Create(c *gin.Context) {
 	var req CreateFestivalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -61,6 +61,22 @@ func (h *Handler) Create(c *gin.Context) {
 
 	festival, err := h.service.Create(c.Request.Context(), req, createdBy)
 	if err != nil {
+		if err == errors.ErrValidation {
+			response.BadRequest(c, "INVALID_TIMEZONE", "Invalid timezone, use an IANA name like Europe/Brussels", nil)
+			return
+		}
+		if err == ErrInvalidDayStart {
+			response.BadRequest(c, "INVALID_DAY_START", "Invalid day start, use a local time like 10:00", nil)
+			return
+		}
+		if err == ErrInvalidDefaultLocale {
+			response.BadRequest(c, "INVALID_LOCALE", "Invalid default locale, use a language tag like en or fr-BE", nil)
+			return
+		}
+		if err == ErrInvalidPreviousEdition {
+			response.BadRequest(c, "INVALID_PREVIOUS_EDITION", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -162,7 +178,7 @@ func (h *Handler) Update(c *gin.Context) {
 
 	var req UpdateFestivalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -172,6 +188,22 @@ func (h *Handler) Update(c *gin.Context) {
 			response.NotFound(c, "Festival not found")
 			return
 		}
+		if err == errors.ErrValidation {
+			response.BadRequest(c, "INVALID_TIMEZONE", "Invalid timezone, use an IANA name like Europe/Brussels", nil)
+			return
+		}
+		if err == ErrInvalidDayStart {
+			response.BadRequest(c, "INVALID_DAY_START", "Invalid day start, use a local time like 10:00", nil)
+			return
+		}
+		if err == ErrInvalidDefaultLocale {
+			response.BadRequest(c, "INVALID_LOCALE", "Invalid default locale, use a language tag like en or fr-BE", nil)
+			return
+		}
+		if err == ErrInvalidPreviousEdition {
+			response.BadRequest(c, "INVALID_PREVIOUS_EDITION", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -1,11 +1,23 @@
 package festival
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
 )
 
+// ErrInvalidPreviousEdition is returned when a festival is linked to an edition that is
+// not another, earlier festival
+var ErrInvalidPreviousEdition = errors.New("previous edition must be another festival starting earlier")
+
+// ErrInvalidDayStart is returned when the operational day start is not an HH:MM time
+var ErrInvalidDayStart = errors.New("day start must be formatted as HH:MM")
+
+// ErrInvalidDefaultLocale is returned when the default locale is not a language tag
+var ErrInvalidDefaultLocale = errors.New("default locale must be a language tag like en or fr-BE")
+
 type Festival struct {
 	ID              uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name            string            `json:"name" gorm:"not null"`
@@ -14,12 +26,18 @@ type Festival struct {
 	StartDate       time.Time         `json:"startDate" gorm:"not null"`
 	EndDate         time.Time         `json:"endDate" gorm:"not null"`
 	Location        string            `json:"location"`
+	Latitude        *float64          `json:"latitude,omitempty"`
+	Longitude       *float64          `json:"longitude,omitempty"`
 	Timezone        string            `json:"timezone" gorm:"default:'Europe/Brussels'"`
+	DayStartsAt     string            `json:"dayStartsAt" gorm:"default:'06:00'"` // Local time the operational day starts at, HH:MM
+	DefaultLocale   string            `json:"defaultLocale" gorm:"default:'en'"`  // Language tag the names of stands, products and categories are written in
 	CurrencyName    string            `json:"currencyName" gorm:"default:'Jetons'"`
 	ExchangeRate    float64           `json:"exchangeRate" gorm:"type:decimal(10,4);default:0.10"`
 	StripeAccountID string            `json:"stripeAccountId,omitempty"`
 	Settings        FestivalSettings  `json:"settings" gorm:"type:jsonb;default:'{}'"`
 	Status          FestivalStatus    `json:"status" gorm:"default:'DRAFT'"`
+	PreviousEditionID *uuid.UUID      `json:"previousEditionId,omitempty" gorm:"type:uuid"` // Earlier edition compared against in the sales analytics
+	Sandbox         bool              `json:"sandbox" gorm:"not null;default:false"` // Test festival, which can run on a test clock
 	CreatedBy       *uuid.UUID        `json:"createdBy,omitempty" gorm:"type:uuid"`
 	CreatedAt       time.Time         `json:"createdAt"`
 	UpdatedAt       time.Time         `json:"updatedAt"`
@@ -39,11 +57,13 @@ const (
 )
 
 type FestivalSettings struct {
-	RefundPolicy   string `json:"refundPolicy"`   // auto, manual, none
-	ReentryPolicy  string `json:"reentryPolicy"`  // single, multiple
-	LogoURL        string `json:"logoUrl,omitempty"`
-	PrimaryColor   string `json:"primaryColor,omitempty"`
-	SecondaryColor string `json:"secondaryColor,omitempty"`
+	RefundPolicy           string `json:"refundPolicy"`                     // auto, manual, none
+	DuplicateChargePolicy  string `json:"duplicateChargePolicy,omitempty"`  // review (default), auto_reverse, off
+	PendingOrderTTLMinutes int    `json:"pendingOrderTtlMinutes,omitempty"` // Unpaid orders older than this are cancelled, 0 never
+	ReentryPolicy          string `json:"reentryPolicy"`                    // single, multiple
+	LogoURL                string `json:"logoUrl,omitempty"`
+	PrimaryColor           string `json:"primaryColor,omitempty"`
+	SecondaryColor         string `json:"secondaryColor,omitempty"`
 }
 
 // CreateFestivalRequest represents the request to create a festival
@@ -53,9 +73,15 @@ type CreateFestivalRequest struct {
 	StartDate    time.Time `json:"startDate" binding:"required"`
 	EndDate      time.Time `json:"endDate" binding:"required"`
 	Location     string    `json:"location"`
+	Latitude     *float64  `json:"latitude" binding:"omitempty,latitude"`
+	Longitude    *float64  `json:"longitude" binding:"omitempty,longitude"`
 	Timezone     string    `json:"timezone"`
+	DayStartsAt  string    `json:"dayStartsAt"` // HH:MM, 06:00 by default
+	DefaultLocale string   `json:"defaultLocale"` // Language tag, en by default
 	CurrencyName string    `json:"currencyName"`
 	ExchangeRate float64   `json:"exchangeRate"`
+	PreviousEditionID *uuid.UUID `json:"previousEditionId"`
+	Sandbox      bool      `json:"sandbox"` // Test festival, set at creation only
 }
 
 // UpdateFestivalRequest represents the request to update a festival
@@ -65,12 +91,17 @@ type UpdateFestivalRequest struct {
 	StartDate       *time.Time        `json:"startDate,omitempty"`
 	EndDate         *time.Time        `json:"endDate,omitempty"`
 	Location        *string           `json:"location,omitempty"`
+	Latitude        *float64          `json:"latitude,omitempty" binding:"omitempty,latitude"`
+	Longitude       *float64          `json:"longitude,omitempty" binding:"omitempty,longitude"`
 	Timezone        *string           `json:"timezone,omitempty"`
+	DayStartsAt     *string           `json:"dayStartsAt,omitempty"`
+	DefaultLocale   *string           `json:"defaultLocale,omitempty"`
 	CurrencyName    *string           `json:"currencyName,omitempty"`
 	ExchangeRate    *float64          `json:"exchangeRate,omitempty"`
 	StripeAccountID *string           `json:"stripeAccountId,omitempty"`
 	Settings        *FestivalSettings `json:"settings,omitempty"`
 	Status          *FestivalStatus   `json:"status,omitempty"`
+	PreviousEditionID *uuid.UUID      `json:"previousEditionId,omitempty"` // uuid.Nil unlinks the previous edition
 }
 
 // FestivalResponse represents the API response for a festival
@@ -82,12 +113,18 @@ type FestivalResponse struct {
 	StartDate       string           `json:"startDate"`
 	EndDate         string           `json:"endDate"`
 	Location        string           `json:"location"`
+	Latitude        *float64         `json:"latitude,omitempty"`
+	Longitude       *float64         `json:"longitude,omitempty"`
 	Timezone        string           `json:"timezone"`
+	DayStartsAt     string           `json:"dayStartsAt"`
+	DefaultLocale   string           `json:"defaultLocale"`
 	CurrencyName    string           `json:"currencyName"`
 	ExchangeRate    float64          `json:"exchangeRate"`
 	StripeAccountID string           `json:"stripeAccountId,omitempty"`
 	Settings        FestivalSettings `json:"settings"`
 	Status          FestivalStatus   `json:"status"`
+	PreviousEditionID *uuid.UUID     `json:"previousEditionId,omitempty"`
+	Sandbox         bool             `json:"sandbox"`
 	CreatedAt       string           `json:"createdAt"`
 	UpdatedAt       string           `json:"updatedAt"`
 }
@@ -101,13 +138,30 @@ func (f *Festival) ToResponse() FestivalResponse {
 		StartDate:       f.StartDate.Format("2006-01-02"),
 		EndDate:         f.EndDate.Format("2006-01-02"),
 		Location:        f.Location,
+		Latitude:        f.Latitude,
+		Longitude:       f.Longitude,
 		Timezone:        f.Timezone,
+		DayStartsAt:     f.DayStartsAt,
+		DefaultLocale:   f.DefaultLocale,
 		CurrencyName:    f.CurrencyName,
 		ExchangeRate:    f.ExchangeRate,
 		StripeAccountID: f.StripeAccountID,
 		Settings:        f.Settings,
 		Status:          f.Status,
+		PreviousEditionID: f.PreviousEditionID,
+		Sandbox:         f.Sandbox,
 		CreatedAt:       f.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       f.UpdatedAt.Format(time.RFC3339),
 	}
 }
+
+// Calendar returns the operational days of the festival, which start at DayStartsAt
+// in its timezone
+func (f *Festival) Calendar() tz.Calendar {
+	return tz.NewCalendar(f.Timezone, f.DayStartsAt)
+}
+
+// HasCoordinates reports whether the festival location has been geocoded
+func (f *Festival) HasCoordinates() bool {
+	return f.Latitude != nil && f.Longitude != nil
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/database"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/text/runes"
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
@@ -18,15 +21,42 @@ import (
 )
 
 type Service struct {
-	repo Repository
-	db   *gorm.DB
+	repo       Repository
+	db         *gorm.DB
+	recomputer DayRecomputer
+}
+
+// DayRecomputer recomputes the daily figures stored for a festival, satisfied by
+// jobs.AnalyticsQueue
+type DayRecomputer interface {
+	RecomputeDays(ctx context.Context, festivalID uuid.UUID) error
 }
 
 func NewService(repo Repository, db *gorm.DB) *Service {
 	return &Service{repo: repo, db: db}
 }
 
+// SetDayRecomputer sets where the daily figures of a festival are recomputed when its
+// operational day boundary changes
+func (s *Service) SetDayRecomputer(recomputer DayRecomputer) {
+	s.recomputer = recomputer
+}
+
 func (s *Service) Create(ctx context.Context, req CreateFestivalRequest, createdBy *uuid.UUID) (*Festival, error) {
+	// Day boundaries of stats and scheduled tasks are computed in this timezone
+	if req.Timezone != "" && !tz.IsValid(req.Timezone) {
+		return nil, errors.ErrValidation
+	}
+	if req.DayStartsAt != "" && !tz.IsValidDayStart(req.DayStartsAt) {
+		return nil, ErrInvalidDayStart
+	}
+	defaultLocale := i18n.Default
+	if req.DefaultLocale != "" {
+		if defaultLocale = i18n.CanonicalTag(req.DefaultLocale); defaultLocale == "" {
+			return nil, ErrInvalidDefaultLocale
+		}
+	}
+
 	// Generate slug from name
 	slug := slugify(req.Name)
 
@@ -43,7 +73,12 @@ func (s *Service) Create(ctx context.Context, req CreateFestivalRequest, created
 	// Set defaults
 	timezone := req.Timezone
 	if timezone == "" {
-		timezone = "Europe/Brussels"
+		timezone = tz.Default
+	}
+
+	dayStartsAt := req.DayStartsAt
+	if dayStartsAt == "" {
+		dayStartsAt = tz.DefaultDayStart
 	}
 
 	currencyName := req.CurrencyName
@@ -57,24 +92,34 @@ func (s *Service) Create(ctx context.Context, req CreateFestivalRequest, created
 	}
 
 	festival := &Festival{
-		ID:           uuid.New(),
-		Name:         req.Name,
-		Slug:         slug,
-		Description:  req.Description,
-		StartDate:    req.StartDate,
-		EndDate:      req.EndDate,
-		Location:     req.Location,
-		Timezone:     timezone,
-		CurrencyName: currencyName,
-		ExchangeRate: exchangeRate,
+		ID:            uuid.New(),
+		Name:          req.Name,
+		Slug:          slug,
+		Description:   req.Description,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		Location:      req.Location,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		Timezone:      timezone,
+		DayStartsAt:   dayStartsAt,
+		DefaultLocale: defaultLocale,
+		CurrencyName:  currencyName,
+		ExchangeRate:  exchangeRate,
 		Settings: FestivalSettings{
 			RefundPolicy:  "manual",
 			ReentryPolicy: "single",
 		},
-		Status:    FestivalStatusDraft,
-		CreatedBy: createdBy,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Status:            FestivalStatusDraft,
+		CreatedBy:         createdBy,
+		PreviousEditionID: req.PreviousEditionID,
+		Sandbox:           req.Sandbox,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := s.validatePreviousEdition(ctx, festival); err != nil {
+		return nil, err
 	}
 
 	// Create festival in database
@@ -151,9 +196,37 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateFestivalRe
 	if req.Location != nil {
 		festival.Location = *req.Location
 	}
+	if req.Latitude != nil {
+		festival.Latitude = req.Latitude
+	}
+	if req.Longitude != nil {
+		festival.Longitude = req.Longitude
+	}
+	// Daily stats and reports follow a new day boundary; closed days keep theirs
+	boundaryChanged := false
 	if req.Timezone != nil {
+		if !tz.IsValid(*req.Timezone) {
+			return nil, errors.ErrValidation
+		}
+		boundaryChanged = *req.Timezone != festival.Timezone
 		festival.Timezone = *req.Timezone
 	}
+	if req.DayStartsAt != nil {
+		if !tz.IsValidDayStart(*req.DayStartsAt) {
+			return nil, ErrInvalidDayStart
+		}
+		boundaryChanged = boundaryChanged || *req.DayStartsAt != festival.DayStartsAt
+		festival.DayStartsAt = *req.DayStartsAt
+	}
+	// Names already translated keep their text in the previous default locale until
+	// they are edited again
+	if req.DefaultLocale != nil {
+		locale := i18n.CanonicalTag(*req.DefaultLocale)
+		if locale == "" {
+			return nil, ErrInvalidDefaultLocale
+		}
+		festival.DefaultLocale = locale
+	}
 	if req.CurrencyName != nil {
 		festival.CurrencyName = *req.CurrencyName
 	}
@@ -169,6 +242,15 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateFestivalRe
 	if req.Status != nil {
 		festival.Status = *req.Status
 	}
+	if req.PreviousEditionID != nil {
+		festival.PreviousEditionID = req.PreviousEditionID
+		if *req.PreviousEditionID == uuid.Nil {
+			festival.PreviousEditionID = nil
+		}
+	}
+	if err := s.validatePreviousEdition(ctx, festival); err != nil {
+		return nil, err
+	}
 
 	festival.UpdatedAt = time.Now()
 
@@ -176,9 +258,51 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateFestivalRe
 		return nil, fmt.Errorf("failed to update festival: %w", err)
 	}
 
+	if boundaryChanged && s.recomputer != nil {
+		if err := s.recomputer.RecomputeDays(ctx, festival.ID); err != nil {
+			log.Warn().Err(err).Str("festival_id", festival.ID.String()).Msg("Failed to enqueue the recomputation of daily figures")
+		}
+	}
+
 	return festival, nil
 }
 
+// DefaultLocale returns the language the names of the stands, products and categories
+// of a festival are written in
+func (s *Service) DefaultLocale(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	festival, err := s.repo.GetByID(ctx, festivalID)
+	if err != nil {
+		return "", err
+	}
+	if festival == nil {
+		return "", errors.ErrNotFound
+	}
+	if festival.DefaultLocale == "" {
+		return i18n.Default, nil
+	}
+	return festival.DefaultLocale, nil
+}
+
+// validatePreviousEdition checks that the previous edition of a festival is another
+// festival starting earlier, which also rules out cycles in the chain of editions
+func (s *Service) validatePreviousEdition(ctx context.Context, festival *Festival) error {
+	if festival.PreviousEditionID == nil {
+		return nil
+	}
+	if *festival.PreviousEditionID == festival.ID {
+		return ErrInvalidPreviousEdition
+	}
+
+	previous, err := s.repo.GetByID(ctx, *festival.PreviousEditionID)
+	if err != nil {
+		return err
+	}
+	if previous == nil || !previous.StartDate.Before(festival.StartDate) {
+		return ErrInvalidPreviousEdition
+	}
+	return nil
+}
+
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 	festival, err := s.repo.GetByID(ctx, id)
 	if err != nil {
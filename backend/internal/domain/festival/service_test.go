@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -206,6 +207,21 @@ func TestService_Create_DuplicateSlug(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestService_Create_InvalidTimezone tests that Create rejects unknown timezones
+func TestService_Create_InvalidTimezone(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := &Service{repo: mockRepo, db: nil}
+
+	festival, err := service.Create(context.Background(), CreateFestivalRequest{
+		Name:     "Timezone Festival",
+		Timezone: "Mars/Olympus_Mons",
+	}, nil)
+
+	assert.ErrorIs(t, err, errors.ErrValidation)
+	assert.Nil(t, festival)
+	mockRepo.AssertNotCalled(t, "ExistsBySlug", mock.Anything, mock.Anything)
+}
+
 // TestService_Update tests the Update method
 func TestService_Update(t *testing.T) {
 	tests := []struct {
@@ -274,6 +290,46 @@ func TestService_Update(t *testing.T) {
 				assert.Equal(t, "New Location", f.Location)
 			},
 		},
+		{
+			name:      "update timezone",
+			festivalID: uuid.New(),
+			req: UpdateFestivalRequest{
+				Timezone: func() *string { s := "America/New_York"; return &s }(),
+			},
+			setupMock: func(m *MockRepository, id uuid.UUID) {
+				existing := &Festival{
+					ID:       id,
+					Name:     "Original Name",
+					Slug:     "original-name",
+					Status:   FestivalStatusDraft,
+					Timezone: "Europe/Brussels",
+				}
+				m.On("GetByID", mock.Anything, id).Return(existing, nil)
+				m.On("Update", mock.Anything, mock.AnythingOfType("*festival.Festival")).Return(nil)
+			},
+			wantErr: false,
+			validate: func(t *testing.T, f *Festival) {
+				assert.Equal(t, "America/New_York", f.Timezone)
+			},
+		},
+		{
+			name:      "update with unknown timezone",
+			festivalID: uuid.New(),
+			req: UpdateFestivalRequest{
+				Timezone: func() *string { s := "Europe/Atlantis"; return &s }(),
+			},
+			setupMock: func(m *MockRepository, id uuid.UUID) {
+				existing := &Festival{
+					ID:       id,
+					Name:     "Original Name",
+					Slug:     "original-name",
+					Status:   FestivalStatusDraft,
+					Timezone: "Europe/Brussels",
+				}
+				m.On("GetByID", mock.Anything, id).Return(existing, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name:      "update non-existent festival",
 			festivalID: uuid.New(),
@@ -312,6 +368,51 @@ func TestService_Update(t *testing.T) {
 	}
 }
 
+type fakeRecomputer struct {
+	festivals []uuid.UUID
+}
+
+func (r *fakeRecomputer) RecomputeDays(ctx context.Context, festivalID uuid.UUID) error {
+	r.festivals = append(r.festivals, festivalID)
+	return nil
+}
+
+// TestService_Update_DayStartsAt tests that moving the day boundary recomputes the daily figures
+func TestService_Update_DayStartsAt(t *testing.T) {
+	mockRepo := NewMockRepository()
+	festivalID := uuid.New()
+
+	existing := &Festival{
+		ID:          festivalID,
+		Name:        "Night Festival",
+		Slug:        "night-festival",
+		Status:      FestivalStatusActive,
+		Timezone:    "Europe/Brussels",
+		DayStartsAt: "06:00",
+	}
+	mockRepo.On("GetByID", mock.Anything, festivalID).Return(existing, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*festival.Festival")).Return(nil)
+
+	recomputer := &fakeRecomputer{}
+	service := &Service{repo: mockRepo, db: nil}
+	service.SetDayRecomputer(recomputer)
+
+	invalid := "25:00"
+	_, err := service.Update(context.Background(), festivalID, UpdateFestivalRequest{DayStartsAt: &invalid})
+	assert.ErrorIs(t, err, ErrInvalidDayStart)
+
+	same := "06:00"
+	_, err = service.Update(context.Background(), festivalID, UpdateFestivalRequest{DayStartsAt: &same})
+	assert.NoError(t, err)
+	assert.Empty(t, recomputer.festivals)
+
+	later := "10:00"
+	festival, err := service.Update(context.Background(), festivalID, UpdateFestivalRequest{DayStartsAt: &later})
+	assert.NoError(t, err)
+	assert.Equal(t, "10:00", festival.DayStartsAt)
+	assert.Equal(t, []uuid.UUID{festivalID}, recomputer.festivals)
+}
+
 // TestService_Activate tests the Activate method
 func TestService_Activate(t *testing.T) {
 	mockRepo := NewMockRepository()
@@ -552,3 +653,42 @@ func TestService_List(t *testing.T) {
 		})
 	}
 }
+
+// TestService_Update_PreviousEdition tests linking a festival to its previous edition
+func TestService_Update_PreviousEdition(t *testing.T) {
+	id := uuid.New()
+	previousID := uuid.New()
+	start := time.Date(2025, 7, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		previousStart time.Time
+		link          uuid.UUID
+		wantErr       error
+	}{
+		{name: "earlier edition", previousStart: start.AddDate(-1, 0, 0), link: previousID},
+		{name: "later edition", previousStart: start.AddDate(1, 0, 0), link: previousID, wantErr: ErrInvalidPreviousEdition},
+		{name: "itself", previousStart: start, link: id, wantErr: ErrInvalidPreviousEdition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRepository()
+			mockRepo.On("GetByID", mock.Anything, id).Return(&Festival{ID: id, StartDate: start}, nil)
+			mockRepo.On("GetByID", mock.Anything, previousID).Return(&Festival{ID: previousID, StartDate: tt.previousStart}, nil)
+			mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*festival.Festival")).Return(nil)
+			service := &Service{repo: mockRepo, db: nil}
+
+			link := tt.link
+			festival, err := service.Update(context.Background(), id, UpdateFestivalRequest{PreviousEditionID: &link})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &previousID, festival.PreviousEditionID)
+		})
+	}
+}
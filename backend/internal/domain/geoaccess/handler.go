@@ -0,0 +1,166 @@
+package geoaccess
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin geo access routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	geo := r.Group("/geo-access")
+	{
+		geo.GET("/rules", h.ListRules)
+		geo.GET("/lookup", h.Lookup)
+		geo.GET("/overrides", h.ListOverrides)
+		geo.POST("/overrides", h.CreateOverride)
+		geo.DELETE("/overrides/:id", h.DeleteOverride)
+	}
+}
+
+// ListRules lists the geo access rules
+// @Summary List geo access rules
+// @Description Get the rules denying or flagging requests to some paths by the country and AS of the client, as configured in the geo access file
+// @Tags geo-access
+// @Produce json
+// @Success 200 {object} response.Response{data=[]Rule} "Rules"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/geo-access/rules [get]
+func (h *Handler) ListRules(c *gin.Context) {
+	response.OK(c, h.service.Rules())
+}
+
+// Lookup explains the decision for an IP
+// @Summary Look up IP
+// @Description Resolve the country and AS of an IP and explain the decision of the rules and overrides for a path. Nothing is recorded.
+// @Tags geo-access
+// @Produce json
+// @Param ip query string true "IP address"
+// @Param path query string false "Request path" default(/api/v1/admin)
+// @Param method query string false "Request method" default(GET)
+// @Success 200 {object} response.Response{data=LookupResult} "Decision"
+// @Failure 400 {object} response.ErrorResponse "Invalid IP address"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/geo-access/lookup [get]
+func (h *Handler) Lookup(c *gin.Context) {
+	result, err := h.service.Lookup(
+		c.Query("ip"),
+		c.DefaultQuery("method", "GET"),
+		c.DefaultQuery("path", "/api/v1/admin"),
+	)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// ListOverrides lists the geo access overrides
+// @Summary List geo access overrides
+// @Description Get the networks and AS allowed or denied on the paths covered by the rules, whatever their location
+// @Tags geo-access
+// @Produce json
+// @Success 200 {object} response.Response{data=[]Override} "Overrides"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/geo-access/overrides [get]
+func (h *Handler) ListOverrides(c *gin.Context) {
+	overrides, err := h.service.ListOverrides(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, overrides)
+}
+
+// CreateOverride adds a geo access override
+// @Summary Create geo access override
+// @Description Allow or deny an IP, a CIDR or an AS number (AS16509) on the paths covered by the rules. The override applies on every instance without a restart.
+// @Tags geo-access
+// @Accept json
+// @Produce json
+// @Param request body CreateOverrideRequest true "Override"
+// @Success 201 {object} response.Response{data=Override} "Override created"
+// @Failure 400 {object} response.ErrorResponse "Invalid override"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/geo-access/overrides [post]
+func (h *Handler) CreateOverride(c *gin.Context) {
+	var req CreateOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	override, err := h.service.CreateOverride(c.Request.Context(), adminID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, override)
+}
+
+// DeleteOverride removes a geo access override
+// @Summary Delete geo access override
+// @Tags geo-access
+// @Param id path string true "Override ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Override not found"
+// @Security BearerAuth
+// @Router /admin/geo-access/overrides/{id} [delete]
+func (h *Handler) DeleteOverride(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid override ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteOverride(c.Request.Context(), id, adminID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func adminID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOverrideNotFound):
+		response.NotFound(c, "Override not found")
+	case errors.Is(err, ErrInvalidOverride):
+		response.BadRequest(c, "INVALID_OVERRIDE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidIP):
+		response.BadRequest(c, "INVALID_IP", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
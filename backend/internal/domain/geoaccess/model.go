@@ -0,0 +1,106 @@
+package geoaccess
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/geoip"
+)
+
+// ReloadChannel is the Redis pub/sub channel override changes are broadcast on, so
+// that every API instance reloads them
+const ReloadChannel = "geoaccess:reload"
+
+// Geo access errors
+var (
+	ErrOverrideNotFound = errors.New("override not found")
+	ErrInvalidOverride  = errors.New("value must be an IP address, a CIDR or an AS number such as AS16509")
+	ErrInvalidIP        = errors.New("invalid IP address")
+	ErrInvalidConfig    = errors.New("invalid geo access config")
+)
+
+// Action is what a rule does with the requests it matches
+type Action string
+
+const (
+	ActionDeny Action = "deny" // Reject with 403
+	ActionFlag Action = "flag" // Let through and record a security event
+)
+
+// Rule matches the requests to some paths from some locations. Every condition given
+// must match; a condition on the country or the ASN never matches a client the
+// databases do not know, such as a private address.
+type Rule struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description,omitempty"`
+	Paths       []string `yaml:"paths" json:"paths"`               // Path prefixes, * matches one segment
+	Methods     []string `yaml:"methods" json:"methods,omitempty"` // Every method when empty
+	Action      Action   `yaml:"action" json:"action"`
+
+	Countries        []string `yaml:"countries" json:"countries,omitempty"`                // ISO codes matched
+	ExcludeCountries []string `yaml:"exclude_countries" json:"excludeCountries,omitempty"` // ISO codes not matched
+	OutsideEU        bool     `yaml:"outside_eu" json:"outsideEu,omitempty"`
+	ASNs             []uint   `yaml:"asns" json:"asns,omitempty"`
+	Datacenter       bool     `yaml:"datacenter" json:"datacenter,omitempty"` // Matches the datacenter ASNs
+}
+
+// Config is the geo access configuration file
+type Config struct {
+	DatacenterASNs []uint `yaml:"datacenter_asns"`
+	Rules          []Rule `yaml:"rules"`
+}
+
+// OverrideKind is what an override matches
+type OverrideKind string
+
+const (
+	OverrideNetwork OverrideKind = "NETWORK" // An IP address or a CIDR
+	OverrideASN     OverrideKind = "ASN"
+)
+
+// Effect is what an override does on the paths covered by the rules
+type Effect string
+
+const (
+	EffectAllow Effect = "ALLOW" // Skip the rules
+	EffectDeny  Effect = "DENY"  // Reject with 403
+)
+
+// Override allows or denies a network or an AS on the paths covered by the rules,
+// whatever their location, e.g. an administrator travelling outside the EU. Network
+// overrides take precedence over AS overrides, and denials over allowances of the
+// same kind.
+type Override struct {
+	ID        uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Kind      OverrideKind `json:"kind" gorm:"not null"`
+	Value     string       `json:"value" gorm:"not null"` // CIDR, or AS number
+	Effect    Effect       `json:"effect" gorm:"not null"`
+	Reason    string       `json:"reason,omitempty"`
+	ExpiresAt *time.Time   `json:"expiresAt,omitempty"`
+	CreatedBy *uuid.UUID   `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+func (Override) TableName() string {
+	return "geo_access_overrides"
+}
+
+// CreateOverrideRequest is the request to add an override
+type CreateOverrideRequest struct {
+	Value     string     `json:"value" binding:"required"` // IP, CIDR or AS number (AS16509)
+	Effect    Effect     `json:"effect" binding:"required,oneof=ALLOW DENY"`
+	Reason    string     `json:"reason" binding:"max=500"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// LookupResult explains the decision for an IP on a path
+type LookupResult struct {
+	IP         string         `json:"ip"`
+	Location   geoip.Location `json:"location"`
+	Datacenter bool           `json:"datacenter"`
+	Override   *Override      `json:"override,omitempty"` // Override applying to the IP
+	Allowed    bool           `json:"allowed"`
+	DeniedBy   string         `json:"deniedBy,omitempty"` // Rule denying the request
+	FlaggedBy  []string       `json:"flaggedBy,omitempty"`
+}
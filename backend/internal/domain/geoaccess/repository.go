@@ -0,0 +1,65 @@
+package geoaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateOverride(ctx context.Context, override *Override) error
+	GetOverride(ctx context.Context, id uuid.UUID) (*Override, error)
+	// ListOverrides lists the overrides not expired at now
+	ListOverrides(ctx context.Context, now time.Time) ([]Override, error)
+	DeleteOverride(ctx context.Context, id uuid.UUID) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateOverride(ctx context.Context, override *Override) error {
+	if err := r.db.WithContext(ctx).Create(override).Error; err != nil {
+		return fmt.Errorf("failed to create geo access override: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetOverride(ctx context.Context, id uuid.UUID) (*Override, error) {
+	var override Override
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&override).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get geo access override: %w", err)
+	}
+	return &override, nil
+}
+
+func (r *repository) ListOverrides(ctx context.Context, now time.Time) ([]Override, error) {
+	var overrides []Override
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&overrides).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list geo access overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+func (r *repository) DeleteOverride(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&Override{}).Error; err != nil {
+		return fmt.Errorf("failed to delete geo access override: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package geoaccess
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateOverride(ctx context.Context, override *Override) error {
+	args := m.Called(ctx, override)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetOverride(ctx context.Context, id uuid.UUID) (*Override, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Override), args.Error(1)
+}
+
+func (m *MockRepository) ListOverrides(ctx context.Context, now time.Time) ([]Override, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]Override), args.Error(1)
+}
+
+func (m *MockRepository) DeleteOverride(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,154 @@
+package geoaccess
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mimi6060/festivals/backend/internal/pkg/geoip"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDatacenterASNs are the networks of the main cloud and hosting providers,
+// where browsers of real users rarely come from
+var DefaultDatacenterASNs = []uint{
+	16509, 14618, // Amazon
+	15169, 396982, // Google
+	8075,   // Microsoft
+	14061,  // DigitalOcean
+	16276,  // OVH
+	24940,  // Hetzner
+	63949,  // Linode
+	20473,  // Vultr
+	12876,  // Scaleway
+	51167,  // Contabo
+	31898,  // Oracle
+	45102,  // Alibaba
+	132203, // Tencent
+}
+
+// LoadConfig reads the geo access rules from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geo access config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse geo access config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks the rules and normalizes their country codes and methods
+func (c *Config) Validate() error {
+	if len(c.DatacenterASNs) == 0 {
+		c.DatacenterASNs = DefaultDatacenterASNs
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("%w: rule %d has no name", ErrInvalidConfig, i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%w: duplicate rule %s", ErrInvalidConfig, rule.Name)
+		}
+		names[rule.Name] = true
+
+		if len(rule.Paths) == 0 {
+			return fmt.Errorf("%w: rule %s has no paths", ErrInvalidConfig, rule.Name)
+		}
+		if rule.Action != ActionDeny && rule.Action != ActionFlag {
+			return fmt.Errorf("%w: rule %s action must be deny or flag", ErrInvalidConfig, rule.Name)
+		}
+		if len(rule.Countries) == 0 && len(rule.ExcludeCountries) == 0 && !rule.OutsideEU && len(rule.ASNs) == 0 && !rule.Datacenter {
+			return fmt.Errorf("%w: rule %s has no condition", ErrInvalidConfig, rule.Name)
+		}
+
+		for j, country := range rule.Countries {
+			rule.Countries[j] = strings.ToUpper(country)
+		}
+		for j, country := range rule.ExcludeCountries {
+			rule.ExcludeCountries[j] = strings.ToUpper(country)
+		}
+		for j, method := range rule.Methods {
+			rule.Methods[j] = strings.ToUpper(method)
+		}
+	}
+	return nil
+}
+
+// coversRequest reports whether the rule applies to a method and path
+func (r *Rule) coversRequest(method, path string) bool {
+	if len(r.Methods) > 0 && !contains(r.Methods, method) {
+		return false
+	}
+	for _, pattern := range r.Paths {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the rule conditions match a location
+func (r *Rule) matches(location geoip.Location, datacenter bool) bool {
+	country := location.CountryCode
+	if len(r.Countries) > 0 && (country == "" || !contains(r.Countries, country)) {
+		return false
+	}
+	if len(r.ExcludeCountries) > 0 && (country == "" || contains(r.ExcludeCountries, country)) {
+		return false
+	}
+	if r.OutsideEU && (country == "" || location.InEU) {
+		return false
+	}
+	if len(r.ASNs) > 0 && (location.ASN == 0 || !containsASN(r.ASNs, location.ASN)) {
+		return false
+	}
+	if r.Datacenter && !datacenter {
+		return false
+	}
+	return true
+}
+
+// matchPath reports whether path starts with the segments of pattern, where *
+// matches any one segment
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) < len(patternSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsASN(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,444 @@
+package geoaccess
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/pkg/geoip"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Resolver resolves the location of client IPs; satisfied by geoip.Resolver
+type Resolver interface {
+	Resolve(ip net.IP) (geoip.Location, error)
+}
+
+// Auditor records the access decisions; satisfied by security.SecurityAuditor
+type Auditor interface {
+	LogEvent(ctx context.Context, event *security.SecurityEvent)
+}
+
+// AuditLogger records override changes; satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service decides the access of client IPs to sensitive surfaces, such as the admin
+// routes or the payment endpoints, from their country and AS. Rules come from the
+// configuration file; overrides are managed at runtime and applied on every instance.
+type Service struct {
+	resolver   Resolver
+	repo       Repository
+	config     Config
+	datacenter map[uint]bool
+	auditor    Auditor
+	audit      AuditLogger
+	redis      *redis.Client
+	now        func() time.Time
+
+	mu        sync.RWMutex
+	overrides []loadedOverride
+}
+
+// loadedOverride is an override parsed for matching
+type loadedOverride struct {
+	Override
+	network *net.IPNet
+	asn     uint
+}
+
+// NewService creates a geo access service enforcing the rules of config
+func NewService(resolver Resolver, repo Repository, config Config) (*Service, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	datacenter := make(map[uint]bool, len(config.DatacenterASNs))
+	for _, asn := range config.DatacenterASNs {
+		datacenter[asn] = true
+	}
+
+	return &Service{
+		resolver:   resolver,
+		repo:       repo,
+		config:     config,
+		datacenter: datacenter,
+		now:        time.Now,
+	}, nil
+}
+
+// SetAuditor records the denied and flagged requests as security events
+func (s *Service) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
+}
+
+// SetAuditLogger records override changes in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// SetBroadcaster tells the other instances to reload the overrides through Redis
+func (s *Service) SetBroadcaster(client *redis.Client) {
+	s.redis = client
+}
+
+// Load loads the overrides enforced by Check
+func (s *Service) Load(ctx context.Context) error {
+	overrides, err := s.repo.ListOverrides(ctx, s.now())
+	if err != nil {
+		return err
+	}
+
+	loaded := make([]loadedOverride, 0, len(overrides))
+	for _, override := range overrides {
+		parsed, err := parseOverride(override)
+		if err != nil {
+			log.Warn().Err(err).Str("override_id", override.ID.String()).Msg("Skipping invalid geo access override")
+			continue
+		}
+		loaded = append(loaded, parsed)
+	}
+
+	s.mu.Lock()
+	s.overrides = loaded
+	s.mu.Unlock()
+
+	log.Info().Int("rules", len(s.config.Rules)).Int("overrides", len(loaded)).Msg("Geo access rules loaded")
+	return nil
+}
+
+// Listen reloads the overrides whenever another instance changes them, until ctx is
+// done
+func (s *Service) Listen(ctx context.Context) error {
+	if s.redis == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	pubsub := s.redis.Subscribe(ctx, ReloadChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to geo access channel: %w", err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := s.Load(ctx); err != nil {
+					log.Error().Err(err).Msg("Failed to reload geo access overrides")
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Check resolves the location of a client IP and decides whether it may access a
+// path. Denied and flagged requests are recorded as security events. Requests are
+// allowed when the IP cannot be resolved.
+func (s *Service) Check(ctx context.Context, ip, method, path string) (geoip.Location, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return geoip.Location{}, true
+	}
+
+	location, err := s.resolver.Resolve(parsed)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to resolve client location")
+		return location, true
+	}
+
+	result := s.evaluate(parsed, location, method, path)
+	event := &security.SecurityEvent{
+		IPAddress: parsed.String(),
+		Resource:  path,
+		Action:    method,
+		Details:   locationDetails(location),
+	}
+	if result.Override != nil {
+		event.Details["override_id"] = result.Override.ID.String()
+	}
+
+	for _, rule := range result.FlaggedBy {
+		flagged := *event
+		flagged.Type = security.EventAuthzGeoFlagged
+		flagged.Severity = security.SeverityWarning
+		flagged.Result = "flagged"
+		flagged.Details = withRule(event.Details, rule)
+		s.log(ctx, &flagged)
+	}
+	if !result.Allowed {
+		event.Type = security.EventAuthzGeoDenied
+		event.Severity = security.SeverityWarning
+		event.Result = "denied"
+		event.Details = withRule(event.Details, result.DeniedBy)
+		s.log(ctx, event)
+	}
+
+	return location, result.Allowed
+}
+
+// Lookup explains the decision for an IP on a path, without recording it
+func (s *Service) Lookup(ip, method, path string) (*LookupResult, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, ErrInvalidIP
+	}
+
+	location, err := s.resolver.Resolve(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.evaluate(parsed, location, strings.ToUpper(method), path)
+	return result, nil
+}
+
+// Rules returns the configured rules
+func (s *Service) Rules() []Rule {
+	return s.config.Rules
+}
+
+// ListOverrides lists the overrides in force
+func (s *Service) ListOverrides(ctx context.Context) ([]Override, error) {
+	return s.repo.ListOverrides(ctx, s.now())
+}
+
+// CreateOverride adds an override and applies it on every instance
+func (s *Service) CreateOverride(ctx context.Context, adminID *uuid.UUID, req CreateOverrideRequest) (*Override, error) {
+	kind, value, err := normalizeOverrideValue(req.Value)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expiresAt is in the past", ErrInvalidOverride)
+	}
+
+	override := &Override{
+		ID:        uuid.New(),
+		Kind:      kind,
+		Value:     value,
+		Effect:    req.Effect,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: adminID,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.CreateOverride(ctx, override); err != nil {
+		return nil, err
+	}
+
+	s.changed(ctx, adminID, "create", override)
+	return override, nil
+}
+
+// DeleteOverride removes an override on every instance
+func (s *Service) DeleteOverride(ctx context.Context, id uuid.UUID, adminID *uuid.UUID) error {
+	override, err := s.repo.GetOverride(ctx, id)
+	if err != nil {
+		return err
+	}
+	if override == nil {
+		return ErrOverrideNotFound
+	}
+
+	if err := s.repo.DeleteOverride(ctx, id); err != nil {
+		return err
+	}
+
+	s.changed(ctx, adminID, "delete", override)
+	return nil
+}
+
+// evaluate applies the overrides and the rules covering a request
+func (s *Service) evaluate(ip net.IP, location geoip.Location, method, path string) *LookupResult {
+	result := &LookupResult{
+		IP:         ip.String(),
+		Location:   location,
+		Datacenter: s.datacenter[location.ASN],
+		Allowed:    true,
+	}
+
+	var rules []*Rule
+	for i := range s.config.Rules {
+		if s.config.Rules[i].coversRequest(method, path) {
+			rules = append(rules, &s.config.Rules[i])
+		}
+	}
+	if len(rules) == 0 {
+		return result
+	}
+
+	if override := s.findOverride(ip, location.ASN); override != nil {
+		result.Override = &override.Override
+		if override.Effect == EffectDeny {
+			result.Allowed = false
+			result.DeniedBy = "override"
+		}
+		return result
+	}
+
+	for _, rule := range rules {
+		if !rule.matches(location, result.Datacenter) {
+			continue
+		}
+		if rule.Action == ActionDeny {
+			result.Allowed = false
+			result.DeniedBy = rule.Name
+			return result
+		}
+		result.FlaggedBy = append(result.FlaggedBy, rule.Name)
+	}
+	return result
+}
+
+// findOverride returns the override applying to an IP: network overrides before AS
+// overrides, denials before allowances
+func (s *Service) findOverride(ip net.IP, asn uint) *loadedOverride {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	var network, autonomous *loadedOverride
+	for i := range s.overrides {
+		o := &s.overrides[i]
+		if o.ExpiresAt != nil && !o.ExpiresAt.After(now) {
+			continue
+		}
+		switch {
+		case o.network != nil && o.network.Contains(ip):
+			if network == nil || o.Effect == EffectDeny {
+				network = o
+			}
+		case o.asn != 0 && o.asn == asn:
+			if autonomous == nil || o.Effect == EffectDeny {
+				autonomous = o
+			}
+		}
+	}
+	if network != nil {
+		return network
+	}
+	return autonomous
+}
+
+// changed reloads the overrides here and on the other instances, and audits the change
+func (s *Service) changed(ctx context.Context, adminID *uuid.UUID, op string, override *Override) {
+	if err := s.Load(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to reload geo access overrides")
+	}
+
+	if s.redis != nil {
+		if err := s.redis.Publish(ctx, ReloadChannel, override.ID.String()).Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to broadcast geo access override change")
+		}
+	}
+
+	if s.audit != nil {
+		s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     adminID,
+			Action:     audit.ActionSettingsUpdate,
+			Resource:   "geo_access_override",
+			ResourceID: override.ID.String(),
+			Metadata: map[string]interface{}{
+				"op":        op,
+				"kind":      override.Kind,
+				"value":     override.Value,
+				"effect":    override.Effect,
+				"reason":    override.Reason,
+				"expiresAt": override.ExpiresAt,
+			},
+		})
+	}
+}
+
+func (s *Service) log(ctx context.Context, event *security.SecurityEvent) {
+	if s.auditor != nil {
+		s.auditor.LogEvent(ctx, event)
+	}
+}
+
+// normalizeOverrideValue parses an IP, a CIDR or an AS number into the value stored
+func normalizeOverrideValue(value string) (OverrideKind, string, error) {
+	value = strings.TrimSpace(value)
+
+	if asn := strings.TrimPrefix(strings.ToUpper(value), "AS"); asn != strings.ToUpper(value) {
+		n, err := strconv.ParseUint(asn, 10, 32)
+		if err != nil || n == 0 {
+			return "", "", ErrInvalidOverride
+		}
+		return OverrideASN, strconv.FormatUint(n, 10), nil
+	}
+
+	if ip := net.ParseIP(value); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		network := &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		return OverrideNetwork, network.String(), nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", "", ErrInvalidOverride
+	}
+	return OverrideNetwork, network.String(), nil
+}
+
+func parseOverride(override Override) (loadedOverride, error) {
+	loaded := loadedOverride{Override: override}
+	switch override.Kind {
+	case OverrideNetwork:
+		_, network, err := net.ParseCIDR(override.Value)
+		if err != nil {
+			return loaded, err
+		}
+		loaded.network = network
+	case OverrideASN:
+		asn, err := strconv.ParseUint(override.Value, 10, 32)
+		if err != nil {
+			return loaded, err
+		}
+		loaded.asn = uint(asn)
+	default:
+		return loaded, fmt.Errorf("unknown override kind %s", override.Kind)
+	}
+	return loaded, nil
+}
+
+func locationDetails(location geoip.Location) map[string]interface{} {
+	return map[string]interface{}{
+		"country":   location.CountryCode,
+		"continent": location.ContinentCode,
+		"in_eu":     location.InEU,
+		"asn":       location.ASN,
+		"as_org":    location.ASOrganization,
+	}
+}
+
+func withRule(details map[string]interface{}, rule string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(details)+1)
+	for k, v := range details {
+		copied[k] = v
+	}
+	copied["rule"] = rule
+	return copied
+}
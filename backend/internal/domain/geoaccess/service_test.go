@@ -0,0 +1,145 @@
+package geoaccess
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/geoip"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver map[string]geoip.Location
+
+func (r fakeResolver) Resolve(ip net.IP) (geoip.Location, error) {
+	return r[ip.String()], nil
+}
+
+type fakeAuditor struct {
+	events []*security.SecurityEvent
+}
+
+func (a *fakeAuditor) LogEvent(ctx context.Context, event *security.SecurityEvent) {
+	a.events = append(a.events, event)
+}
+
+var (
+	paris  = geoip.Location{CountryCode: "FR", ContinentCode: "EU", InEU: true, ASN: 3215}
+	boston = geoip.Location{CountryCode: "US", ContinentCode: "NA", ASN: 7922}
+	aws    = geoip.Location{CountryCode: "DE", ContinentCode: "EU", InEU: true, ASN: 16509}
+)
+
+func newTestService(t *testing.T, repo Repository) (*Service, *fakeAuditor) {
+	resolver := fakeResolver{"192.0.2.1": paris, "198.51.100.1": boston, "203.0.113.1": aws}
+	config := Config{Rules: []Rule{
+		{Name: "admin-eu-only", Paths: []string{"/api/v1/admin"}, Action: ActionDeny, OutsideEU: true},
+		{Name: "payments-datacenter", Paths: []string{"/api/v1/stripe", "/api/v1/festivals/*/wallets"}, Methods: []string{"post"}, Action: ActionFlag, Datacenter: true},
+	}}
+
+	auditor := &fakeAuditor{}
+	service, err := NewService(resolver, repo, config)
+	require.NoError(t, err)
+	service.SetAuditor(auditor)
+	return service, auditor
+}
+
+func TestService_CheckRules(t *testing.T) {
+	service, auditor := newTestService(t, NewMockRepository())
+	ctx := context.Background()
+
+	location, allowed := service.Check(ctx, "198.51.100.1", "GET", "/api/v1/admin/users")
+	assert.False(t, allowed)
+	assert.Equal(t, "US", location.CountryCode)
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, security.EventAuthzGeoDenied, auditor.events[0].Type)
+	assert.Equal(t, "admin-eu-only", auditor.events[0].Details["rule"])
+
+	_, allowed = service.Check(ctx, "192.0.2.1", "GET", "/api/v1/admin/users")
+	assert.True(t, allowed)
+
+	// Paths not covered by a rule, and unknown locations such as private addresses
+	_, allowed = service.Check(ctx, "198.51.100.1", "GET", "/api/v1/festivals")
+	assert.True(t, allowed)
+	_, allowed = service.Check(ctx, "10.0.0.5", "GET", "/api/v1/admin/users")
+	assert.True(t, allowed)
+	_, allowed = service.Check(ctx, "198.51.100.1", "GET", "/api/v1/administrators")
+	assert.True(t, allowed)
+	assert.Len(t, auditor.events, 1)
+
+	// Datacenters are flagged on payments, not denied
+	_, allowed = service.Check(ctx, "203.0.113.1", "POST", "/api/v1/festivals/"+uuid.NewString()+"/wallets/topup")
+	assert.True(t, allowed)
+	require.Len(t, auditor.events, 2)
+	assert.Equal(t, security.EventAuthzGeoFlagged, auditor.events[1].Type)
+	assert.Equal(t, "payments-datacenter", auditor.events[1].Details["rule"])
+	assert.Equal(t, uint(16509), auditor.events[1].Details["asn"])
+
+	_, allowed = service.Check(ctx, "203.0.113.1", "GET", "/api/v1/stripe/payment-intents")
+	assert.True(t, allowed)
+	assert.Len(t, auditor.events, 2)
+}
+
+func TestService_Overrides(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(t, mockRepo)
+	ctx := context.Background()
+	adminID := uuid.New()
+	mockRepo.On("CreateOverride", mock.Anything, mock.AnythingOfType("*geoaccess.Override")).Return(nil)
+
+	// The overrides are reloaded after each change
+	bostonAllowed := Override{ID: uuid.New(), Kind: OverrideNetwork, Value: "198.51.100.1/32", Effect: EffectAllow}
+	awsDenied := Override{ID: uuid.New(), Kind: OverrideASN, Value: "16509", Effect: EffectDeny}
+	awsNetworkAllowed := Override{ID: uuid.New(), Kind: OverrideNetwork, Value: "203.0.113.0/24", Effect: EffectAllow}
+
+	// An administrator travelling outside the EU
+	mockRepo.On("ListOverrides", mock.Anything, mock.Anything).Return([]Override{bostonAllowed}, nil).Once()
+	allow, err := service.CreateOverride(ctx, &adminID, CreateOverrideRequest{Value: "198.51.100.1", Effect: EffectAllow, Reason: "Conference in Boston"})
+	require.NoError(t, err)
+	assert.Equal(t, OverrideNetwork, allow.Kind)
+	assert.Equal(t, "198.51.100.1/32", allow.Value)
+	_, allowed := service.Check(ctx, "198.51.100.1", "GET", "/api/v1/admin/users")
+	assert.True(t, allowed)
+
+	// A provider denied whatever the country
+	mockRepo.On("ListOverrides", mock.Anything, mock.Anything).Return([]Override{bostonAllowed, awsDenied}, nil).Once()
+	deny, err := service.CreateOverride(ctx, &adminID, CreateOverrideRequest{Value: "as16509", Effect: EffectDeny})
+	require.NoError(t, err)
+	assert.Equal(t, OverrideASN, deny.Kind)
+	assert.Equal(t, "16509", deny.Value)
+	_, allowed = service.Check(ctx, "203.0.113.1", "GET", "/api/v1/admin/users")
+	assert.False(t, allowed)
+
+	// Networks take precedence over AS
+	mockRepo.On("ListOverrides", mock.Anything, mock.Anything).Return([]Override{bostonAllowed, awsDenied, awsNetworkAllowed}, nil).Once()
+	_, err = service.CreateOverride(ctx, &adminID, CreateOverrideRequest{Value: "203.0.113.0/24", Effect: EffectAllow})
+	require.NoError(t, err)
+	result, err := service.Lookup("203.0.113.1", "GET", "/api/v1/admin")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.True(t, result.Datacenter)
+	assert.Equal(t, OverrideNetwork, result.Override.Kind)
+
+	mockRepo.On("GetOverride", mock.Anything, bostonAllowed.ID).Return(&bostonAllowed, nil).Once()
+	mockRepo.On("DeleteOverride", mock.Anything, bostonAllowed.ID).Return(nil).Once()
+	mockRepo.On("ListOverrides", mock.Anything, mock.Anything).Return([]Override{awsDenied, awsNetworkAllowed}, nil).Once()
+	require.NoError(t, service.DeleteOverride(ctx, bostonAllowed.ID, &adminID))
+	_, allowed = service.Check(ctx, "198.51.100.1", "GET", "/api/v1/admin/users")
+	assert.False(t, allowed)
+
+	mockRepo.On("GetOverride", mock.Anything, bostonAllowed.ID).Return(nil, nil).Once()
+	assert.ErrorIs(t, service.DeleteOverride(ctx, bostonAllowed.ID, &adminID), ErrOverrideNotFound)
+
+	for _, value := range []string{"AS0", "ASx", "300.1.1.1", "example.com"} {
+		_, err := service.CreateOverride(ctx, &adminID, CreateOverrideRequest{Value: value, Effect: EffectDeny})
+		assert.ErrorIs(t, err, ErrInvalidOverride, value)
+	}
+	mockRepo.AssertNumberOfCalls(t, "CreateOverride", 3)
+	mockRepo.AssertExpectations(t)
+
+	_, err = NewService(fakeResolver{}, mockRepo, Config{Rules: []Rule{{Name: "empty", Paths: []string{"/"}, Action: ActionDeny}}})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -0,0 +1,112 @@
+package honeypot
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterDecoyRoutes registers the decoy endpoints, for every method, on the root of
+// the router
+func (h *Handler) RegisterDecoyRoutes(r gin.IRoutes) {
+	for _, path := range h.service.Paths() {
+		r.Any(path, h.Trap)
+	}
+}
+
+// RegisterRoutes registers the block list administration routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	blocked := r.Group("/blocked-ips")
+	{
+		blocked.GET("", h.List)
+		blocked.DELETE("/:ip", h.Unblock)
+	}
+}
+
+// Trap records a request to a decoy endpoint and blocks its client. It answers like
+// an unknown route so scanners learn nothing.
+func (h *Handler) Trap(c *gin.Context) {
+	_, err := h.service.RecordHit(c.Request.Context(), Hit{
+		IP:        c.ClientIP(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("path", c.Request.URL.Path).Msg("Failed to record honeypot hit")
+	}
+
+	c.String(http.StatusNotFound, "404 page not found")
+}
+
+// List lists the blocked IPs
+// @Summary List blocked IPs
+// @Description List the client IPs blocked after requesting a decoy endpoint, until their block expires
+// @Tags honeypot
+// @Produce json
+// @Success 200 {object} response.Response{data=[]BlockedIP} "Blocked IPs"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/blocked-ips [get]
+func (h *Handler) List(c *gin.Context) {
+	blocked, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, blocked)
+}
+
+// Unblock lifts the block of an IP
+// @Summary Unblock IP
+// @Description Remove a client IP from the block list before its block expires
+// @Tags honeypot
+// @Param ip path string true "IP address"
+// @Success 204 "Unblocked"
+// @Failure 400 {object} response.ErrorResponse "Invalid IP address"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "IP address not blocked"
+// @Security BearerAuth
+// @Router /admin/blocked-ips/{ip} [delete]
+func (h *Handler) Unblock(c *gin.Context) {
+	if err := h.service.Unblock(c.Request.Context(), c.Param("ip"), currentUser(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidIP):
+		response.BadRequest(c, "INVALID_IP", err.Error(), nil)
+	case errors.Is(err, ErrNotBlocked):
+		response.NotFound(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
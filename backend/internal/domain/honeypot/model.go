@@ -0,0 +1,65 @@
+package honeypot
+
+import (
+	"errors"
+	"time"
+)
+
+// Honeypot errors
+var (
+	ErrInvalidIP  = errors.New("invalid IP address")
+	ErrNotBlocked = errors.New("IP address is not blocked")
+)
+
+// DefaultBlockTTL is how long a client hitting a decoy endpoint stays blocked
+const DefaultBlockTTL = 24 * time.Hour
+
+// DefaultPaths are decoy endpoints that no client of the API requests, only scanners
+// looking for exposed CMS, admin panels and secrets
+var DefaultPaths = []string{
+	"/wp-admin",
+	"/wp-login.php",
+	"/xmlrpc.php",
+	"/.env",
+	"/.git/config",
+	"/.aws/credentials",
+	"/phpmyadmin",
+	"/config.php",
+	"/server-status",
+	"/actuator/env",
+}
+
+// Config configures the decoy endpoints and the blocking of their clients
+type Config struct {
+	Paths           []string
+	BlockTTL        time.Duration
+	TrustedNetworks []string // CIDRs never blocked, e.g. the office or the uptime monitors
+}
+
+// DefaultConfig returns the default honeypot configuration
+func DefaultConfig() Config {
+	return Config{
+		Paths:    DefaultPaths,
+		BlockTTL: DefaultBlockTTL,
+	}
+}
+
+// Hit is a request to a decoy endpoint
+type Hit struct {
+	IP        string
+	Method    string
+	Path      string
+	UserAgent string
+	RequestID string
+}
+
+// BlockedIP is a client IP rejected by the blocking middleware until it expires
+type BlockedIP struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	Method    string    `json:"method,omitempty"`
+	Path      string    `json:"path,omitempty"` // Decoy endpoint that was requested
+	UserAgent string    `json:"userAgent,omitempty"`
+	BlockedAt time.Time `json:"blockedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
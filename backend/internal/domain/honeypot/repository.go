@@ -0,0 +1,110 @@
+package honeypot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys of the block list; the index scores each blocked IP by its expiry so
+// the list can be read without scanning the keys
+const (
+	blockedKeyPrefix = "honeypot:blocked:"
+	blockedIndexKey  = "honeypot:blocked"
+)
+
+type Repository interface {
+	Block(ctx context.Context, blocked *BlockedIP) error
+	IsBlocked(ctx context.Context, ip string) (bool, error)
+	// List lists the IPs blocked at now
+	List(ctx context.Context, now time.Time) ([]BlockedIP, error)
+	// Unblock removes an IP from the block list and reports whether it was blocked
+	Unblock(ctx context.Context, ip string) (bool, error)
+}
+
+type repository struct {
+	redisClient *redis.Client
+}
+
+// NewRepository creates a block list stored in Redis, where entries expire with their
+// block
+func NewRepository(redisClient *redis.Client) Repository {
+	return &repository{redisClient: redisClient}
+}
+
+func (r *repository) Block(ctx context.Context, blocked *BlockedIP) error {
+	data, err := json.Marshal(blocked)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blocked IP: %w", err)
+	}
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.Set(ctx, blockedKeyPrefix+blocked.IP, data, time.Until(blocked.ExpiresAt))
+	pipe.ZAdd(ctx, blockedIndexKey, redis.Z{Score: float64(blocked.ExpiresAt.Unix()), Member: blocked.IP})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to block IP: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) IsBlocked(ctx context.Context, ip string) (bool, error) {
+	count, err := r.redisClient.Exists(ctx, blockedKeyPrefix+ip).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check blocked IP: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *repository) List(ctx context.Context, now time.Time) ([]BlockedIP, error) {
+	// Drop the expired entries of the index before reading it
+	expired := strconv.FormatInt(now.Unix(), 10)
+	if err := r.redisClient.ZRemRangeByScore(ctx, blockedIndexKey, "-inf", expired).Err(); err != nil {
+		return nil, fmt.Errorf("failed to prune blocked IPs: %w", err)
+	}
+
+	ips, err := r.redisClient.ZRange(ctx, blockedIndexKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked IPs: %w", err)
+	}
+	if len(ips) == 0 {
+		return []BlockedIP{}, nil
+	}
+
+	keys := make([]string, len(ips))
+	for i, ip := range ips {
+		keys[i] = blockedKeyPrefix + ip
+	}
+	values, err := r.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked IPs: %w", err)
+	}
+
+	blocked := make([]BlockedIP, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Expired since the index was read
+		}
+		var entry BlockedIP
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal blocked IP: %w", err)
+		}
+		blocked = append(blocked, entry)
+	}
+	return blocked, nil
+}
+
+func (r *repository) Unblock(ctx context.Context, ip string) (bool, error) {
+	pipe := r.redisClient.TxPipeline()
+	deleted := pipe.Del(ctx, blockedKeyPrefix+ip)
+	pipe.ZRem(ctx, blockedIndexKey, ip)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to unblock IP: %w", err)
+	}
+	return deleted.Val() > 0, nil
+}
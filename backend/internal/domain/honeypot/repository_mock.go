@@ -0,0 +1,40 @@
+package honeypot
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) Block(ctx context.Context, blocked *BlockedIP) error {
+	args := m.Called(ctx, blocked)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IsBlocked(ctx context.Context, ip string) (bool, error) {
+	args := m.Called(ctx, ip)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context, now time.Time) ([]BlockedIP, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]BlockedIP), args.Error(1)
+}
+
+func (m *MockRepository) Unblock(ctx context.Context, ip string) (bool, error) {
+	args := m.Called(ctx, ip)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,187 @@
+package honeypot
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/rs/zerolog/log"
+)
+
+// Auditor records security events; satisfied by security.SecurityAuditor
+type Auditor interface {
+	LogEvent(ctx context.Context, event *security.SecurityEvent)
+}
+
+// Service blocks the clients of the decoy endpoints. Only scanners request them, so
+// their IP is added to a block list the blocking middleware enforces on every route
+// until the block expires or an administrator lifts it.
+type Service struct {
+	repo    Repository
+	config  Config
+	trusted []*net.IPNet
+	auditor Auditor
+	now     func() time.Time
+}
+
+// NewService creates a honeypot service. It fails when a trusted network is not a
+// valid CIDR.
+func NewService(repo Repository, config Config) (*Service, error) {
+	if config.BlockTTL <= 0 {
+		config.BlockTTL = DefaultBlockTTL
+	}
+
+	trusted := make([]*net.IPNet, 0, len(config.TrustedNetworks))
+	for _, cidr := range config.TrustedNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted network %q: %w", cidr, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return &Service{
+		repo:    repo,
+		config:  config,
+		trusted: trusted,
+		now:     time.Now,
+	}, nil
+}
+
+// SetAuditor sets the auditor recording the decoy hits and unblocks
+func (s *Service) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
+}
+
+// Paths returns the decoy endpoints
+func (s *Service) Paths() []string {
+	return s.config.Paths
+}
+
+// IsBlocked reports whether a client IP is blocked
+func (s *Service) IsBlocked(ctx context.Context, ip string) (bool, error) {
+	normalized, err := normalizeIP(ip)
+	if err != nil {
+		return false, nil
+	}
+	return s.repo.IsBlocked(ctx, normalized)
+}
+
+// RecordHit records a request to a decoy endpoint as an attack and blocks its client,
+// unless it comes from a trusted network. It returns the block, nil when the client
+// was not blocked.
+func (s *Service) RecordHit(ctx context.Context, hit Hit) (*BlockedIP, error) {
+	ip, err := normalizeIP(hit.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	trusted := s.isTrusted(ip)
+	result := "blocked"
+	if trusted {
+		result = "trusted"
+	}
+	s.audit(ctx, &security.SecurityEvent{
+		Type:      security.EventAttackHoneypot,
+		Severity:  security.SeverityWarning,
+		RequestID: hit.RequestID,
+		IPAddress: ip,
+		UserAgent: hit.UserAgent,
+		Resource:  hit.Path,
+		Action:    hit.Method,
+		Result:    result,
+		Details: map[string]interface{}{
+			"block_ttl": s.config.BlockTTL.String(),
+		},
+	})
+	if trusted {
+		return nil, nil
+	}
+
+	now := s.now()
+	blocked := &BlockedIP{
+		IP:        ip,
+		Reason:    "honeypot",
+		Method:    hit.Method,
+		Path:      hit.Path,
+		UserAgent: hit.UserAgent,
+		BlockedAt: now,
+		ExpiresAt: now.Add(s.config.BlockTTL),
+	}
+	if err := s.repo.Block(ctx, blocked); err != nil {
+		return nil, err
+	}
+
+	log.Warn().
+		Str("ip", ip).
+		Str("path", hit.Path).
+		Time("expires_at", blocked.ExpiresAt).
+		Msg("Blocked client IP after honeypot hit")
+
+	return blocked, nil
+}
+
+// List lists the blocked IPs
+func (s *Service) List(ctx context.Context) ([]BlockedIP, error) {
+	return s.repo.List(ctx, s.now())
+}
+
+// Unblock lifts the block of an IP before it expires, e.g. for a festival partner
+// caught by a misconfigured tool
+func (s *Service) Unblock(ctx context.Context, ip string, unblockedBy *uuid.UUID) error {
+	normalized, err := normalizeIP(ip)
+	if err != nil {
+		return err
+	}
+
+	unblocked, err := s.repo.Unblock(ctx, normalized)
+	if err != nil {
+		return err
+	}
+	if !unblocked {
+		return ErrNotBlocked
+	}
+
+	event := &security.SecurityEvent{
+		Type:      security.EventSystemConfigChange,
+		Severity:  security.SeverityInfo,
+		IPAddress: normalized,
+		Resource:  "ip_block_list",
+		Action:    "unblock",
+		Result:    "success",
+	}
+	if unblockedBy != nil {
+		event.UserID = unblockedBy.String()
+	}
+	s.audit(ctx, event)
+	return nil
+}
+
+func (s *Service) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	for _, network := range s.trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) audit(ctx context.Context, event *security.SecurityEvent) {
+	if s.auditor != nil {
+		s.auditor.LogEvent(ctx, event)
+	}
+}
+
+// normalizeIP returns the canonical form of an IP, so IPv6 addresses written
+// differently share one entry
+func normalizeIP(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", ErrInvalidIP
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,109 @@
+package honeypot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditor struct {
+	events []*security.SecurityEvent
+}
+
+func (a *fakeAuditor) LogEvent(ctx context.Context, event *security.SecurityEvent) {
+	a.events = append(a.events, event)
+}
+
+var testNow = time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T, repo Repository, config Config) (*Service, *fakeAuditor) {
+	auditor := &fakeAuditor{}
+	service, err := NewService(repo, config)
+	require.NoError(t, err)
+	service.SetAuditor(auditor)
+	service.now = func() time.Time { return testNow }
+	return service, auditor
+}
+
+// blockOf matches the block of an IP
+func blockOf(ip string) interface{} {
+	return mock.MatchedBy(func(blocked *BlockedIP) bool { return blocked.IP == ip })
+}
+
+func TestService_RecordHitBlocksClient(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, auditor := newTestService(t, mockRepo, DefaultConfig())
+	ctx := context.Background()
+
+	mockRepo.On("Block", mock.Anything, blockOf("203.0.113.7")).Return(nil).Once()
+	blocked, err := service.RecordHit(ctx, Hit{IP: "203.0.113.7", Method: "GET", Path: "/.env", UserAgent: "zgrab/0.x"})
+	require.NoError(t, err)
+	require.NotNil(t, blocked)
+	assert.Equal(t, testNow.Add(DefaultBlockTTL), blocked.ExpiresAt)
+	assert.Equal(t, "/.env", blocked.Path)
+
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, security.EventAttackHoneypot, auditor.events[0].Type)
+	assert.Equal(t, "203.0.113.7", auditor.events[0].IPAddress)
+	assert.Equal(t, "blocked", auditor.events[0].Result)
+
+	mockRepo.On("IsBlocked", mock.Anything, "203.0.113.7").Return(true, nil).Once()
+	isBlocked, err := service.IsBlocked(ctx, "203.0.113.7")
+	require.NoError(t, err)
+	assert.True(t, isBlocked)
+
+	// IPv6 addresses are compared in their canonical form
+	mockRepo.On("Block", mock.Anything, blockOf("2001:db8::1")).Return(nil).Once()
+	_, err = service.RecordHit(ctx, Hit{IP: "2001:DB8:0:0::1", Path: "/wp-admin"})
+	require.NoError(t, err)
+	mockRepo.On("IsBlocked", mock.Anything, "2001:db8::1").Return(true, nil).Once()
+	isBlocked, err = service.IsBlocked(ctx, "2001:0db8:0000::1")
+	require.NoError(t, err)
+	assert.True(t, isBlocked)
+
+	_, err = service.RecordHit(ctx, Hit{IP: "not-an-ip"})
+	assert.ErrorIs(t, err, ErrInvalidIP)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_TrustedNetworksAndUnblock(t *testing.T) {
+	config := DefaultConfig()
+	config.TrustedNetworks = []string{"10.0.0.0/8"}
+	mockRepo := NewMockRepository()
+	service, auditor := newTestService(t, mockRepo, config)
+	ctx := context.Background()
+
+	// Hits from trusted networks are audited but never blocked
+	blocked, err := service.RecordHit(ctx, Hit{IP: "10.1.2.3", Path: "/server-status"})
+	require.NoError(t, err)
+	assert.Nil(t, blocked)
+	mockRepo.AssertNotCalled(t, "Block", mock.Anything, mock.Anything)
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, "trusted", auditor.events[0].Result)
+
+	mockRepo.On("Block", mock.Anything, blockOf("198.51.100.4")).Return(nil).Once()
+	_, err = service.RecordHit(ctx, Hit{IP: "198.51.100.4", Path: "/wp-login.php"})
+	require.NoError(t, err)
+
+	mockRepo.On("List", mock.Anything, testNow).Return([]BlockedIP{{IP: "198.51.100.4", ExpiresAt: testNow.Add(DefaultBlockTTL)}}, nil).Once()
+	list, err := service.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	adminID := uuid.New()
+	mockRepo.On("Unblock", mock.Anything, "198.51.100.4").Return(true, nil).Once()
+	require.NoError(t, service.Unblock(ctx, "198.51.100.4", &adminID))
+	assert.Equal(t, adminID.String(), auditor.events[len(auditor.events)-1].UserID)
+	mockRepo.On("Unblock", mock.Anything, "198.51.100.4").Return(false, nil).Once()
+	assert.ErrorIs(t, service.Unblock(ctx, "198.51.100.4", &adminID), ErrNotBlocked)
+
+	_, err = NewService(mockRepo, Config{TrustedNetworks: []string{"10.0.0.0"}})
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -77,7 +77,7 @@ func getCurrentUser(c *gin.Context) (uuid.UUID, string) {
 func (h *Handler) CreateItem(c *gin.Context) {
 	var req CreateInventoryItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -156,7 +156,7 @@ func (h *Handler) UpdateItem(c *gin.Context) {
 
 	var req UpdateInventoryItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -223,7 +223,7 @@ func (h *Handler) GetStock(c *gin.Context) {
 func (h *Handler) AdjustStock(c *gin.Context) {
 	var req AdjustStockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -242,7 +242,7 @@ func (h *Handler) AdjustStock(c *gin.Context) {
 func (h *Handler) RecordSale(c *gin.Context) {
 	var req RecordSaleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -359,7 +359,7 @@ func (h *Handler) AcknowledgeAlert(c *gin.Context) {
 func (h *Handler) CreateCount(c *gin.Context) {
 	var req CreateCountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -460,7 +460,7 @@ func (h *Handler) RecordCountItem(c *gin.Context) {
 		Notes     string    `json:"notes"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -489,7 +489,7 @@ func (h *Handler) ReconcileCount(c *gin.Context) {
 
 	var req ReconcileCountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
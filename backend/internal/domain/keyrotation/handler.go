@@ -0,0 +1,98 @@
+package keyrotation
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin key rotation routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	keys := r.Group("/keys")
+	{
+		keys.GET("", h.List)
+		keys.POST("/reload", h.Reload)
+		keys.DELETE("/:fingerprint", h.Revoke)
+	}
+}
+
+// List lists the accepted signing secrets
+// @Summary List signing secrets
+// @Description Get the fingerprints of the current signing secret and of the previous ones still accepted, with the end of their overlap window
+// @Tags keys
+// @Produce json
+// @Success 200 {object} response.Response{data=[]KeyInfo} "Accepted secrets"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/keys [get]
+func (h *Handler) List(c *gin.Context) {
+	response.OK(c, h.service.Keys())
+}
+
+// Reload reloads the signing secrets and Auth0 keys
+// @Summary Reload signing keys
+// @Description Re-read the signing secrets file and fetch the Auth0 signing keys on every instance, without a restart. The replaced secret stays accepted for the overlap window.
+// @Tags keys
+// @Produce json
+// @Success 200 {object} response.Response{data=ReloadResult} "Reload result"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 500 {object} response.ErrorResponse "Secrets could not be loaded"
+// @Security BearerAuth
+// @Router /admin/keys/reload [post]
+func (h *Handler) Reload(c *gin.Context) {
+	result, err := h.service.Reload(c.Request.Context(), TriggerAdmin, actorID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// Revoke stops accepting a previous signing secret
+// @Summary Revoke signing secret
+// @Description Stop accepting a previous signing secret on every instance before its overlap window ends
+// @Tags keys
+// @Param fingerprint path string true "Secret fingerprint"
+// @Success 204 "Secret revoked"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Secret not found"
+// @Security BearerAuth
+// @Router /admin/keys/{fingerprint} [delete]
+func (h *Handler) Revoke(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), c.Param("fingerprint"), actorID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func actorID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		response.NotFound(c, "Previous secret not found")
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
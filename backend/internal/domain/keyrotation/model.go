@@ -0,0 +1,54 @@
+package keyrotation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+// BroadcastChannel is the Redis pub/sub channel rotations are broadcast on, so that a
+// reload or revocation on one API or worker instance applies to all of them
+const BroadcastChannel = "keyrotation:events"
+
+// ErrKeyNotFound is returned when revoking a secret that is not a previous secret
+var ErrKeyNotFound = errors.New("previous secret not found")
+
+// Trigger is what started a reload
+type Trigger string
+
+const (
+	TriggerSignal    Trigger = "signal"    // SIGHUP
+	TriggerAdmin     Trigger = "admin"     // Admin endpoint
+	TriggerBroadcast Trigger = "broadcast" // Rotation on another instance
+)
+
+// KeyInfo describes an accepted signing secret by its fingerprint
+type KeyInfo = security.KeyInfo
+
+// JWKSChange lists the Auth0 signing keys added to and removed from the JWKS, by kid
+type JWKSChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Changed reports whether the JWKS changed
+func (c JWKSChange) Changed() bool {
+	return len(c.Added) > 0 || len(c.Removed) > 0
+}
+
+// ReloadResult reports what a reload changed
+type ReloadResult struct {
+	Trigger    Trigger                `json:"trigger"`
+	Secrets    security.KeyringChange `json:"secrets"`
+	JWKS       JWKSChange             `json:"jwks"`
+	ReloadedAt time.Time              `json:"reloadedAt"`
+}
+
+// broadcastMessage tells the other instances to reload or revoke
+type broadcastMessage struct {
+	Op          string `json:"op"` // "reload" or "revoke"
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Source      string `json:"source"` // Instance that sent the message
+}
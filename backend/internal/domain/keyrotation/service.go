@@ -0,0 +1,268 @@
+package keyrotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// SecretsLoader returns the current and previous signing secrets, satisfied by
+// config.Config.LoadJWTSecrets
+type SecretsLoader func() (string, []string, error)
+
+// JWKSReloader fetches the Auth0 signing keys and returns the kids added and
+// removed, satisfied by middleware.ReloadJWKS
+type JWKSReloader func(ctx context.Context) ([]string, []string, error)
+
+// AuditLogger records rotations, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service rotates the signing secrets and Auth0 keys of a running instance
+type Service struct {
+	mu         sync.Mutex // Serializes reloads
+	keyring    *security.Keyring
+	load       SecretsLoader
+	jwks       JWKSReloader
+	audit      AuditLogger
+	redis      *redis.Client
+	instanceID string
+}
+
+// NewService creates a key rotation service reloading keyring from load
+func NewService(keyring *security.Keyring, load SecretsLoader) *Service {
+	return &Service{
+		keyring:    keyring,
+		load:       load,
+		instanceID: uuid.NewString(),
+	}
+}
+
+// SetJWKSReloader makes reloads also fetch the Auth0 signing keys
+func (s *Service) SetJWKSReloader(jwks JWKSReloader) {
+	s.jwks = jwks
+}
+
+// SetAuditLogger records rotations in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// SetBroadcaster broadcasts rotations to the other instances through Redis
+func (s *Service) SetBroadcaster(client *redis.Client) {
+	s.redis = client
+}
+
+// Keys describes the accepted secrets without revealing them
+func (s *Service) Keys() []KeyInfo {
+	return s.keyring.Keys()
+}
+
+// Reload re-reads the signing secrets and fetches the Auth0 signing keys, then tells
+// the other instances to do the same. The replaced secret stays accepted for the
+// overlap window. A failed JWKS fetch is reported in the result without failing the
+// reload, since the cached keys stay in use.
+func (s *Service) Reload(ctx context.Context, trigger Trigger, actorID *uuid.UUID) (*ReloadResult, error) {
+	result, err := s.reload(ctx, trigger, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if trigger != TriggerBroadcast {
+		s.broadcast(ctx, broadcastMessage{Op: "reload"})
+	}
+	return result, nil
+}
+
+func (s *Service) reload(ctx context.Context, trigger Trigger, actorID *uuid.UUID) (*ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, previous, err := s.load()
+	if err != nil {
+		log.Error().Err(err).Str("trigger", string(trigger)).Msg("Failed to reload signing secrets")
+		s.record(ctx, trigger, actorID, audit.ActionKeyRotate, "signing_secret", "", map[string]interface{}{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+
+	result := &ReloadResult{
+		Trigger:    trigger,
+		Secrets:    s.keyring.Rotate(current, previous),
+		ReloadedAt: time.Now(),
+	}
+
+	if s.jwks != nil {
+		added, removed, err := s.jwks(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("trigger", string(trigger)).Msg("Failed to reload Auth0 signing keys")
+			result.JWKS.Error = err.Error()
+		}
+		result.JWKS.Added = added
+		result.JWKS.Removed = removed
+	}
+
+	log.Info().
+		Str("trigger", string(trigger)).
+		Bool("secret_rotated", result.Secrets.Rotated).
+		Str("current", result.Secrets.Current).
+		Strs("jwks_added", result.JWKS.Added).
+		Strs("jwks_removed", result.JWKS.Removed).
+		Msg("Signing keys reloaded")
+
+	if result.Secrets.Changed() {
+		s.record(ctx, trigger, actorID, audit.ActionKeyRotate, "signing_secret", result.Secrets.Current, map[string]interface{}{
+			"status":   "rotated",
+			"rotated":  result.Secrets.Rotated,
+			"replaced": result.Secrets.Replaced,
+			"added":    result.Secrets.Added,
+		})
+	}
+	if result.JWKS.Changed() || result.JWKS.Error != "" {
+		status := "rotated"
+		if result.JWKS.Error != "" {
+			status = "failed"
+		}
+		s.record(ctx, trigger, actorID, audit.ActionKeyRotate, "auth0_jwks", "", map[string]interface{}{
+			"status":  status,
+			"added":   result.JWKS.Added,
+			"removed": result.JWKS.Removed,
+			"error":   result.JWKS.Error,
+		})
+	}
+
+	return result, nil
+}
+
+// Revoke stops accepting a previous secret before its overlap window ends, on every
+// instance
+func (s *Service) Revoke(ctx context.Context, fingerprint string, actorID *uuid.UUID) error {
+	if !s.keyring.Revoke(fingerprint) {
+		return ErrKeyNotFound
+	}
+
+	log.Warn().Str("fingerprint", fingerprint).Msg("Signing secret revoked")
+	s.record(ctx, TriggerAdmin, actorID, audit.ActionKeyRevoke, "signing_secret", fingerprint, nil)
+	s.broadcast(ctx, broadcastMessage{Op: "revoke", Fingerprint: fingerprint})
+	return nil
+}
+
+// ReloadOnSignal reloads whenever the process receives SIGHUP, until ctx is done
+func (s *Service) ReloadOnSignal(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			// Failures are logged and audited by Reload
+			_, _ = s.Reload(ctx, TriggerSignal, nil)
+		}
+	}
+}
+
+// Listen applies the rotations broadcast by other instances until ctx is done
+func (s *Service) Listen(ctx context.Context) error {
+	if s.redis == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	pubsub := s.redis.Subscribe(ctx, BroadcastChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to key rotation channel: %w", err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				s.handleMessage(ctx, msg)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *Service) handleMessage(ctx context.Context, msg *redis.Message) {
+	var m broadcastMessage
+	if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+		log.Warn().Err(err).Msg("Failed to unmarshal key rotation message")
+		return
+	}
+	if m.Source == s.instanceID {
+		return
+	}
+
+	switch m.Op {
+	case "reload":
+		_, _ = s.Reload(ctx, TriggerBroadcast, nil)
+	case "revoke":
+		if s.keyring.Revoke(m.Fingerprint) {
+			log.Warn().Str("fingerprint", m.Fingerprint).Msg("Signing secret revoked by another instance")
+		}
+	}
+}
+
+// broadcast tells the other instances to apply a rotation. Instances that miss it
+// catch up on their next SIGHUP or restart.
+func (s *Service) broadcast(ctx context.Context, m broadcastMessage) {
+	if s.redis == nil {
+		return
+	}
+
+	m.Source = s.instanceID
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Publish(ctx, BroadcastChannel, string(data)).Err(); err != nil {
+		log.Warn().Err(err).Str("op", m.Op).Msg("Failed to broadcast key rotation")
+	}
+}
+
+// record writes a rotation to the audit log. Broadcast reloads are recorded by the
+// instance that started them.
+func (s *Service) record(ctx context.Context, trigger Trigger, actorID *uuid.UUID, action audit.AuditAction, resource, resourceID string, metadata map[string]interface{}) {
+	if s.audit == nil || trigger == TriggerBroadcast {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["trigger"] = string(trigger)
+	metadata["instance"] = s.instanceID
+
+	s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+		UserID:     actorID,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Metadata:   metadata,
+	})
+}
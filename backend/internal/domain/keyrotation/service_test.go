@@ -0,0 +1,98 @@
+package keyrotation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditLogger struct {
+	logs []audit.CreateAuditLogRequest
+}
+
+func (l *fakeAuditLogger) LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest) {
+	l.logs = append(l.logs, req)
+}
+
+// TestReload_RotatesAndAudits tests that a reload rotates the secrets, fetches the
+// JWKS and records both changes
+func TestReload_RotatesAndAudits(t *testing.T) {
+	keyring := security.NewKeyring("old-secret", nil, time.Hour)
+	secrets := []string{"new-secret"}
+	service := NewService(keyring, func() (string, []string, error) {
+		return secrets[0], secrets[1:], nil
+	})
+	service.SetJWKSReloader(func(ctx context.Context) ([]string, []string, error) {
+		return []string{"kid-2"}, []string{"kid-1"}, nil
+	})
+	logger := &fakeAuditLogger{}
+	service.SetAuditLogger(logger)
+
+	result, err := service.Reload(context.Background(), TriggerAdmin, nil)
+	require.NoError(t, err)
+
+	assert.True(t, result.Secrets.Rotated)
+	assert.Equal(t, security.Fingerprint([]byte("old-secret")), result.Secrets.Replaced)
+	assert.Equal(t, []string{"kid-2"}, result.JWKS.Added)
+	assert.Equal(t, "new-secret", string(keyring.Current()))
+	assert.Len(t, service.Keys(), 2, "the replaced secret is accepted during the overlap window")
+
+	require.Len(t, logger.logs, 2)
+	assert.Equal(t, audit.ActionKeyRotate, logger.logs[0].Action)
+	assert.Equal(t, "signing_secret", logger.logs[0].Resource)
+	assert.Equal(t, "admin", logger.logs[0].Metadata["trigger"])
+	assert.Equal(t, "auth0_jwks", logger.logs[1].Resource)
+}
+
+// TestReload_LoadFailureKeepsSecrets tests that a secrets file that cannot be read
+// leaves the keyring unchanged
+func TestReload_LoadFailureKeepsSecrets(t *testing.T) {
+	keyring := security.NewKeyring("old-secret", nil, time.Hour)
+	service := NewService(keyring, func() (string, []string, error) {
+		return "", nil, errors.New("permission denied")
+	})
+	logger := &fakeAuditLogger{}
+	service.SetAuditLogger(logger)
+
+	_, err := service.Reload(context.Background(), TriggerSignal, nil)
+	assert.Error(t, err)
+	assert.Equal(t, "old-secret", string(keyring.Current()))
+
+	require.Len(t, logger.logs, 1)
+	assert.Equal(t, "failed", logger.logs[0].Metadata["status"])
+}
+
+// TestReload_UnchangedIsNotAudited tests that reloading the same secrets records nothing
+func TestReload_UnchangedIsNotAudited(t *testing.T) {
+	service := NewService(security.NewKeyring("secret", nil, time.Hour), func() (string, []string, error) {
+		return "secret", nil, nil
+	})
+	logger := &fakeAuditLogger{}
+	service.SetAuditLogger(logger)
+
+	result, err := service.Reload(context.Background(), TriggerSignal, nil)
+	require.NoError(t, err)
+	assert.False(t, result.Secrets.Changed())
+	assert.Empty(t, logger.logs)
+}
+
+// TestRevoke tests revoking previous secrets
+func TestRevoke(t *testing.T) {
+	keyring := security.NewKeyring("new-secret", []string{"old-secret"}, time.Hour)
+	service := NewService(keyring, nil)
+	logger := &fakeAuditLogger{}
+	service.SetAuditLogger(logger)
+
+	assert.ErrorIs(t, service.Revoke(context.Background(), security.Fingerprint([]byte("new-secret")), nil), ErrKeyNotFound)
+	require.NoError(t, service.Revoke(context.Background(), security.Fingerprint([]byte("old-secret")), nil))
+
+	assert.Len(t, service.Keys(), 1)
+	require.Len(t, logger.logs, 1)
+	assert.Equal(t, audit.ActionKeyRevoke, logger.logs[0].Action)
+}
@@ -0,0 +1,233 @@
+package legacyimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// maxFileSize is the largest CSV file accepted, enough for MaxRows rows
+const maxFileSize = 10 << 20
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped legacy imports, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	imports := r.Group("/legacy-imports")
+	{
+		imports.GET("", h.List)
+		imports.POST("", h.Create)
+		imports.GET("/formats", h.Formats)
+		imports.GET("/:importId", h.Get)
+	}
+}
+
+// Formats lists the columns read for each kind of record
+// @Summary List legacy import formats
+// @Description List the fields of the wallet, transaction and product files, with the header aliases of the exports of other cashless providers
+// @Tags legacy-imports
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Mapper} "Import formats"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/legacy-imports/formats [get]
+func (h *Handler) Formats(c *gin.Context) {
+	response.OK(c, Mappers())
+}
+
+// Create imports a CSV export of a previous cashless provider
+// @Summary Import legacy data
+// @Description Import the wallets, transactions or products of a CSV export of a previous cashless provider. Import the wallets before their transactions. Rows imported before from the same source are skipped, so a file can be imported again after fixing its invalid rows. With dryRun=true the file is validated and nothing is saved.
+// @Tags legacy-imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param dryRun query bool false "Validate the file only"
+// @Param file formData file true "CSV file"
+// @Param kind formData string true "WALLETS, TRANSACTIONS or PRODUCTS"
+// @Param source formData string true "Previous provider, e.g. acme-cashless"
+// @Param amountUnit formData string false "CENTS or DECIMAL (default)"
+// @Param columns formData string false "JSON object mapping fields to the headers of the file"
+// @Success 200 {object} response.Response{data=Result} "Dry run result"
+// @Success 201 {object} response.Response{data=Result} "Import result"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or file"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/legacy-imports [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req ImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+	if columns := c.PostForm("columns"); columns != "" {
+		if err := json.Unmarshal([]byte(columns), &req.Columns); err != nil {
+			response.BadRequest(c, "INVALID_COLUMNS", "Columns must be a JSON object of field names to headers", nil)
+			return
+		}
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "MISSING_FILE", "No file provided", nil)
+		return
+	}
+	if header.Size > maxFileSize {
+		response.BadRequest(c, "PAYLOAD_TOO_LARGE", fmt.Sprintf("CSV file must be under %d MB", maxFileSize>>20), nil)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.BadRequest(c, "INVALID_FILE", "Could not read the file", nil)
+		return
+	}
+	defer file.Close()
+
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	result, err := h.service.Run(c.Request.Context(), festivalID, req, header.Filename, file, dryRun, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if dryRun {
+		response.OK(c, result)
+		return
+	}
+	response.Created(c, result)
+}
+
+// List lists the legacy imports of the festival
+// @Summary List legacy imports
+// @Description List the legacy imports of the festival, latest first, without their row errors
+// @Tags legacy-imports
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Import,meta=response.Meta} "Legacy imports"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/legacy-imports [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	page, perPage := pagination(c)
+	imports, total, err := h.service.List(c.Request.Context(), festivalID, (page-1)*perPage, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, imports, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Get returns a legacy import
+// @Summary Get a legacy import
+// @Description Get a legacy import with the rows it did not import
+// @Tags legacy-imports
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param importId path string true "Import ID" format(uuid)
+// @Success 200 {object} response.Response{data=Import} "Legacy import"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Import not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/legacy-imports/{importId} [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	importID, err := uuid.Parse(c.Param("importId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid import ID", nil)
+		return
+	}
+
+	imp, err := h.service.Get(c.Request.Context(), festivalID, importID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, imp)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrImportNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrFestivalMissing):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrInvalidKind):
+		response.BadRequest(c, "INVALID_KIND", err.Error(), nil)
+	case errors.Is(err, ErrInvalidUnit):
+		response.BadRequest(c, "INVALID_AMOUNT_UNIT", err.Error(), nil)
+	case errors.Is(err, ErrInvalidSource):
+		response.BadRequest(c, "INVALID_SOURCE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidColumns):
+		response.BadRequest(c, "INVALID_COLUMNS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidCSV):
+		response.BadRequest(c, "INVALID_FILE", err.Error(), nil)
+	case errors.Is(err, ErrMissingColumns):
+		response.BadRequest(c, "MISSING_COLUMNS", err.Error(), nil)
+	case errors.Is(err, ErrTooManyRows):
+		response.BadRequest(c, "TOO_MANY_ROWS", fmt.Sprintf("An import file has at most %d rows", MaxRows), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
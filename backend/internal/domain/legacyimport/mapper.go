@@ -0,0 +1,335 @@
+package legacyimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+)
+
+// Field is a column a mapper reads, found under its name or one of the aliases used by
+// the exports of other cashless providers
+type Field struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+}
+
+// Mapper reads the rows of a CSV export of one kind of record
+type Mapper struct {
+	Kind   Kind    `json:"kind"`
+	Fields []Field `json:"fields"`
+}
+
+var externalIDField = Field{Name: "external_id", Aliases: []string{"id", "uuid"}, Required: true, Description: "ID of the record at the previous provider, keeps re-runs idempotent"}
+
+var standField = Field{Name: "stand", Aliases: []string{"stand_name", "point_of_sale", "pos", "pos_name", "location", "bar", "shop"}, Description: "Stand name, matched to the stands of the festival"}
+
+var createdAtField = Field{Name: "created_at", Aliases: []string{"date", "datetime", "timestamp", "creation_date", "created"}, Description: "RFC 3339, YYYY-MM-DD HH:MM[:SS], DD/MM/YYYY HH:MM or YYYY-MM-DD, in the festival timezone without an offset"}
+
+var mappers = map[Kind]Mapper{
+	KindWallets: {
+		Kind: KindWallets,
+		Fields: []Field{
+			withAliases(externalIDField, "wallet_id", "uid", "chip_id", "card_id", "wristband_id", "nfc_uid", "tag_id"),
+			{Name: "email", Aliases: []string{"mail", "user_email", "customer_email", "owner_email"}, Description: "Links the wallet to the account with this email, anonymous otherwise"},
+			{Name: "balance", Aliases: []string{"remaining_balance", "current_balance", "credit", "solde"}, Required: true, Description: "Balance left on the wallet"},
+			{Name: "status", Aliases: []string{"state", "wallet_status"}, Description: "Closed, blocked, disabled or inactive wallets are imported closed"},
+			createdAtField,
+		},
+	},
+	KindTransactions: {
+		Kind: KindTransactions,
+		Fields: []Field{
+			withAliases(externalIDField, "transaction_id", "operation_id", "tx_id"),
+			{Name: "wallet_external_id", Aliases: []string{"wallet_id", "uid", "chip_id", "card_id", "wristband_id", "nfc_uid", "tag_id"}, Required: true, Description: "ID of a wallet imported before from the same source"},
+			{Name: "type", Aliases: []string{"transaction_type", "operation", "operation_type", "kind"}, Required: true, Description: "Top-up, cash-in, purchase, refund or cash-out, in the usual wordings"},
+			{Name: "amount", Aliases: []string{"value", "total", "montant"}, Required: true, Description: "Amount; the sign is taken from the type"},
+			{Name: "balance_after", Aliases: []string{"balance", "new_balance", "solde"}, Description: "Balance of the wallet after the transaction"},
+			standField,
+			{Name: "reference", Aliases: []string{"ref", "receipt", "order_id", "receipt_number"}, Description: "Reference at the previous provider"},
+			withRequired(createdAtField),
+		},
+	},
+	KindProducts: {
+		Kind: KindProducts,
+		Fields: []Field{
+			withAliases(externalIDField, "product_id", "article_id", "item_id"),
+			withRequired(standField),
+			{Name: "name", Aliases: []string{"product", "product_name", "article", "item", "label"}, Required: true, Description: "Product name"},
+			{Name: "price", Aliases: []string{"unit_price", "sale_price", "prix"}, Required: true, Description: "Selling price"},
+			{Name: "category", Aliases: []string{"category_name", "family", "product_type"}, Description: "Beer, cocktail, soft, food, snack or merch; other otherwise"},
+			{Name: "sku", Aliases: []string{"ean", "barcode", "code"}, Description: "Stock keeping unit"},
+		},
+	},
+}
+
+func withAliases(f Field, aliases ...string) Field {
+	f.Aliases = append(append([]string{}, f.Aliases...), aliases...)
+	return f
+}
+
+func withRequired(f Field) Field {
+	f.Required = true
+	return f
+}
+
+// Mappers returns the mappers of every kind of record, for the dashboard to show the
+// columns it expects
+func Mappers() []Mapper {
+	list := make([]Mapper, 0, len(mappers))
+	for _, kind := range []Kind{KindWallets, KindTransactions, KindProducts} {
+		list = append(list, mappers[kind])
+	}
+	return list
+}
+
+// Row is a row of an import file, its values keyed by field name
+type Row struct {
+	Line       int
+	ExternalID string
+	Values     map[string]string
+}
+
+// Parse reads the rows of an import file with the mapper of kind. A field is read from
+// the header columns maps it to, else from the header matching its name or one of its
+// aliases. Rows without an external ID or repeating one are returned as errors.
+func Parse(r io.Reader, kind Kind, columns map[string]string) ([]Row, RowErrors, error) {
+	mapper, ok := mappers[kind]
+	if !ok {
+		return nil, nil, ErrInvalidKind
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, ErrInvalidCSV
+	}
+	// Exports from some spreadsheets and tills are semicolon separated
+	if len(header) == 1 && strings.Contains(header[0], ";") {
+		header = strings.Split(header[0], ";")
+		reader.Comma = ';'
+	}
+
+	positions := map[string]int{}
+	for i, name := range header {
+		positions[normalizeHeader(name)] = i
+	}
+
+	known := map[string]bool{}
+	for _, f := range mapper.Fields {
+		known[f.Name] = true
+	}
+	for name := range columns {
+		if !known[name] {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidColumns, name)
+		}
+	}
+
+	fieldCols := map[string]int{}
+	var missing []string
+	for _, f := range mapper.Fields {
+		col, found := -1, false
+		if header, ok := columns[f.Name]; ok {
+			col, found = positions[normalizeHeader(header)]
+		} else {
+			for _, name := range append([]string{f.Name}, f.Aliases...) {
+				if col, found = positions[name]; found {
+					break
+				}
+			}
+		}
+		if found {
+			fieldCols[f.Name] = col
+		} else if f.Required {
+			missing = append(missing, f.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	var rows []Row
+	errs := RowErrors{}
+	seen := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, RowError{Line: line, Reason: "unreadable row"})
+			continue
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows)+len(errs) >= MaxRows {
+			return nil, nil, ErrTooManyRows
+		}
+
+		row := Row{Line: line, Values: make(map[string]string, len(fieldCols))}
+		for name, col := range fieldCols {
+			if col < len(record) {
+				row.Values[name] = strings.TrimSpace(record[col])
+			}
+		}
+		row.ExternalID = row.Values[externalIDField.Name]
+		switch {
+		case row.ExternalID == "":
+			errs = append(errs, RowError{Line: line, Reason: "no external ID"})
+		case seen[row.ExternalID] > 0:
+			errs = append(errs, RowError{Line: line, ExternalID: row.ExternalID, Reason: fmt.Sprintf("external ID already on line %d", seen[row.ExternalID])})
+		default:
+			seen[row.ExternalID] = line
+			rows = append(rows, row)
+		}
+	}
+	return rows, errs, nil
+}
+
+// normalizeHeader turns "Wallet ID" or "wallet-id" into wallet_id
+func normalizeHeader(name string) string {
+	// Spreadsheets may start the file with a byte order mark
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "_").Replace(name)
+}
+
+// parseAmount reads an amount in cents or in currency units with a dot or a comma
+func parseAmount(raw string, unit AmountUnit) (int64, error) {
+	value := strings.NewReplacer(" ", "", "\u00a0", "", "€", "", "$", "", "£", "").Replace(raw)
+	if unit == AmountUnitCents {
+		return strconv.ParseInt(value, 10, 64)
+	}
+	if strings.Contains(value, ",") {
+		// 1.234,50 or 1234,50
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	return int64(math.Round(amount * 100)), nil
+}
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"2006-01-02",
+	"02/01/2006",
+}
+
+// parseTime reads a date of an export, in loc when it has no offset
+func parseTime(raw string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", raw)
+}
+
+var transactionTypes = map[string]wallet.TransactionType{
+	"top_up":        wallet.TransactionTypeTopUp,
+	"topup":         wallet.TransactionTypeTopUp,
+	"reload":        wallet.TransactionTypeTopUp,
+	"recharge":      wallet.TransactionTypeTopUp,
+	"credit":        wallet.TransactionTypeTopUp,
+	"load":          wallet.TransactionTypeTopUp,
+	"online_top_up": wallet.TransactionTypeTopUp,
+	"cash_in":       wallet.TransactionTypeCashIn,
+	"cashin":        wallet.TransactionTypeCashIn,
+	"cash_top_up":   wallet.TransactionTypeCashIn,
+	"cash_reload":   wallet.TransactionTypeCashIn,
+	"purchase":      wallet.TransactionTypePurchase,
+	"sale":          wallet.TransactionTypePurchase,
+	"payment":       wallet.TransactionTypePurchase,
+	"debit":         wallet.TransactionTypePurchase,
+	"order":         wallet.TransactionTypePurchase,
+	"achat":         wallet.TransactionTypePurchase,
+	"vente":         wallet.TransactionTypePurchase,
+	"refund":        wallet.TransactionTypeRefund,
+	"cancellation":  wallet.TransactionTypeRefund,
+	"void":          wallet.TransactionTypeRefund,
+	"remboursement": wallet.TransactionTypeRefund,
+	"cash_out":      wallet.TransactionTypeCashOut,
+	"cashout":       wallet.TransactionTypeCashOut,
+	"withdrawal":    wallet.TransactionTypeCashOut,
+	"payout":        wallet.TransactionTypeCashOut,
+}
+
+// parseTransactionType maps the wording of a transaction type to ours
+func parseTransactionType(raw string) (wallet.TransactionType, bool) {
+	t, ok := transactionTypes[normalizeHeader(raw)]
+	return t, ok
+}
+
+// isDebit reports whether transactions of type take money from the wallet
+func isDebit(t wallet.TransactionType) bool {
+	return t == wallet.TransactionTypePurchase || t == wallet.TransactionTypeCashOut
+}
+
+var categories = map[string]product.ProductCategory{
+	"beer":        product.ProductCategoryBeer,
+	"beers":       product.ProductCategoryBeer,
+	"biere":       product.ProductCategoryBeer,
+	"bière":       product.ProductCategoryBeer,
+	"cocktail":    product.ProductCategoryCocktail,
+	"cocktails":   product.ProductCategoryCocktail,
+	"soft":        product.ProductCategorySoft,
+	"softs":       product.ProductCategorySoft,
+	"soda":        product.ProductCategorySoft,
+	"soft_drink":  product.ProductCategorySoft,
+	"food":        product.ProductCategoryFood,
+	"snack":       product.ProductCategorySnack,
+	"snacks":      product.ProductCategorySnack,
+	"merch":       product.ProductCategoryMerch,
+	"merchandise": product.ProductCategoryMerch,
+}
+
+// parseCategory maps the category of a product to ours, OTHER when unknown
+func parseCategory(raw string) product.ProductCategory {
+	if category, ok := categories[normalizeHeader(raw)]; ok {
+		return category
+	}
+	return product.ProductCategoryOther
+}
+
+// isClosedStatus reports whether the status of a wallet at the previous provider means
+// it can no longer be used
+func isClosedStatus(raw string) bool {
+	switch normalizeHeader(raw) {
+	case "closed", "blocked", "disabled", "inactive", "deleted", "cancelled", "canceled":
+		return true
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a set in order, for stable queries
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,135 @@
+package legacyimport
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Legacy import errors
+var (
+	ErrImportNotFound  = errors.New("legacy import not found")
+	ErrInvalidKind     = errors.New("kind must be WALLETS, TRANSACTIONS or PRODUCTS")
+	ErrInvalidUnit     = errors.New("amountUnit must be CENTS or DECIMAL")
+	ErrInvalidSource   = errors.New("source must be 2 to 50 letters, digits, dashes or underscores")
+	ErrInvalidColumns  = errors.New("columns map unknown fields")
+	ErrInvalidCSV      = errors.New("CSV file needs a header row")
+	ErrMissingColumns  = errors.New("CSV file misses required columns")
+	ErrTooManyRows     = errors.New("CSV file has too many rows")
+	ErrFestivalMissing = errors.New("festival not found")
+)
+
+// MaxRows is the largest number of rows in an import file; bigger exports are split
+const MaxRows = 50000
+
+// maxStoredErrors caps the row errors kept with an import
+const maxStoredErrors = 1000
+
+// Kind is the kind of record a file imports
+type Kind string
+
+const (
+	KindWallets      Kind = "WALLETS"
+	KindTransactions Kind = "TRANSACTIONS"
+	KindProducts     Kind = "PRODUCTS"
+)
+
+func (k Kind) IsValid() bool {
+	switch k {
+	case KindWallets, KindTransactions, KindProducts:
+		return true
+	}
+	return false
+}
+
+// AmountUnit tells how the amounts of a file are written
+type AmountUnit string
+
+const (
+	AmountUnitCents   AmountUnit = "CENTS"   // 1250
+	AmountUnitDecimal AmountUnit = "DECIMAL" // 12.50 or 12,50
+)
+
+func (u AmountUnit) IsValid() bool {
+	return u == AmountUnitCents || u == AmountUnitDecimal
+}
+
+// Import is a file of wallets, transactions or products exported from a previous
+// cashless provider and imported into a festival. The records it created are tagged
+// with its ID, which the reports show as their source.
+type Import struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID      uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Source          string     `json:"source" gorm:"not null"` // Previous provider, e.g. "acme-cashless"
+	Kind            Kind       `json:"kind" gorm:"not null"`
+	FileName        string     `json:"fileName"`
+	TotalRows       int        `json:"totalRows" gorm:"not null"`
+	Imported        int        `json:"imported" gorm:"not null"`
+	AlreadyImported int        `json:"alreadyImported" gorm:"not null"` // Rows imported by an earlier run
+	Invalid         int        `json:"invalid" gorm:"not null"`
+	TotalAmount     int64      `json:"totalAmount" gorm:"not null"` // Balances, amounts or prices imported, in cents
+	Errors          RowErrors  `json:"errors" gorm:"type:jsonb;serializer:json"`
+	CreatedBy       *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+func (Import) TableName() string {
+	return "legacy_imports"
+}
+
+// RowError is a row of a file that was not imported
+type RowError struct {
+	Line       int    `json:"line"` // The header is line 1
+	ExternalID string `json:"externalId,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// RowErrors are the rows of a file that were not imported
+type RowErrors []RowError
+
+// Record links the ID of a record at the previous provider to the record created for
+// it, so that running an import again skips the rows already imported
+type Record struct {
+	FestivalID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Source     string    `gorm:"primaryKey"`
+	Kind       Kind      `gorm:"primaryKey"`
+	ExternalID string    `gorm:"primaryKey"`
+	EntityID   uuid.UUID `gorm:"type:uuid;not null"`
+	ImportID   uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt  time.Time
+}
+
+func (Record) TableName() string {
+	return "legacy_import_records"
+}
+
+// User is an account a wallet row is linked to by email
+type User struct {
+	ID        uuid.UUID
+	Email     string
+	HasWallet bool // Already has a wallet in the festival
+}
+
+// Result is the outcome of an import, or of its dry run which saves nothing
+type Result struct {
+	ImportID        *uuid.UUID `json:"importId,omitempty"` // Nil for dry runs
+	DryRun          bool       `json:"dryRun"`
+	Kind            Kind       `json:"kind"`
+	Source          string     `json:"source"`
+	TotalRows       int        `json:"totalRows"`
+	Imported        int        `json:"imported"` // Would be imported, for dry runs
+	AlreadyImported int        `json:"alreadyImported"`
+	Invalid         int        `json:"invalid"`
+	TotalAmount     int64      `json:"totalAmount"`
+	Errors          RowErrors  `json:"errors"`
+}
+
+// ImportRequest describes an import file. Columns maps the fields of the mapper to the
+// headers of the file when they are not among the known aliases.
+type ImportRequest struct {
+	Kind       Kind              `form:"kind" binding:"required"`
+	Source     string            `form:"source" binding:"required"`
+	AmountUnit AmountUnit        `form:"amountUnit"` // DECIMAL when empty
+	Columns    map[string]string `form:"-"`          // Read from the columns JSON field
+}
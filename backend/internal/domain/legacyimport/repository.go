@@ -0,0 +1,189 @@
+package legacyimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"gorm.io/gorm"
+)
+
+// insertBatchSize is the number of rows inserted per statement
+const insertBatchSize = 500
+
+// Batch is what a non-dry run creates, saved in one database transaction
+type Batch struct {
+	Import       *Import
+	Records      []Record
+	Wallets      []wallet.Wallet
+	Transactions []wallet.Transaction
+	Products     []product.Product
+}
+
+type Repository interface {
+	// GetFestivalTimezone returns the timezone of a festival, empty when it does not exist
+	GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error)
+	// ListImported returns the records already imported for external IDs, by external ID
+	ListImported(ctx context.Context, festivalID uuid.UUID, source string, kind Kind, externalIDs []string) (map[string]uuid.UUID, error)
+	// ListUsers returns the accounts of emails, by lowercase email, with whether they
+	// have a wallet in the festival
+	ListUsers(ctx context.Context, festivalID uuid.UUID, emails []string) (map[string]User, error)
+	// ListStands returns the stands of a festival by lowercase name
+	ListStands(ctx context.Context, festivalID uuid.UUID) (map[string]uuid.UUID, error)
+
+	SaveBatch(ctx context.Context, batch *Batch) error
+	GetImport(ctx context.Context, festivalID, id uuid.UUID) (*Import, error)
+	ListImports(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Import, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	var timezones []string
+	err := r.db.WithContext(ctx).
+		Table("public.festivals").
+		Where("id = ?", festivalID).
+		Limit(1).
+		Pluck("timezone", &timezones).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(timezones) == 0 {
+		return "", nil
+	}
+	if timezones[0] == "" {
+		return "UTC", nil
+	}
+	return timezones[0], nil
+}
+
+func (r *repository) ListImported(ctx context.Context, festivalID uuid.UUID, source string, kind Kind, externalIDs []string) (map[string]uuid.UUID, error) {
+	imported := make(map[string]uuid.UUID)
+	for start := 0; start < len(externalIDs); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(externalIDs))
+		var records []Record
+		err := r.db.WithContext(ctx).
+			Where("festival_id = ? AND source = ? AND kind = ? AND external_id IN ?", festivalID, source, kind, externalIDs[start:end]).
+			Find(&records).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to list imported records: %w", err)
+		}
+		for _, record := range records {
+			imported[record.ExternalID] = record.EntityID
+		}
+	}
+	return imported, nil
+}
+
+func (r *repository) ListUsers(ctx context.Context, festivalID uuid.UUID, emails []string) (map[string]User, error) {
+	users := make(map[string]User)
+	for start := 0; start < len(emails); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(emails))
+		var rows []User
+		err := r.db.WithContext(ctx).Raw(`
+			SELECT u.id, LOWER(u.email) AS email,
+				EXISTS (SELECT 1 FROM public.wallets w WHERE w.user_id = u.id AND w.festival_id = ?) AS has_wallet
+			FROM public.users u
+			WHERE LOWER(u.email) IN ?`,
+			festivalID, emails[start:end]).Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, user := range rows {
+			users[user.Email] = user
+		}
+	}
+	return users, nil
+}
+
+func (r *repository) ListStands(ctx context.Context, festivalID uuid.UUID) (map[string]uuid.UUID, error) {
+	var rows []struct {
+		ID   uuid.UUID
+		Name string
+	}
+	err := r.db.WithContext(ctx).
+		Table("public.stands").
+		Select("id, name").
+		Where("festival_id = ?", festivalID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stands: %w", err)
+	}
+
+	stands := make(map[string]uuid.UUID, len(rows))
+	for _, row := range rows {
+		stands[strings.ToLower(strings.TrimSpace(row.Name))] = row.ID
+	}
+	return stands, nil
+}
+
+func (r *repository) SaveBatch(ctx context.Context, batch *Batch) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch.Import).Error; err != nil {
+			return err
+		}
+		if len(batch.Wallets) > 0 {
+			if err := tx.CreateInBatches(batch.Wallets, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(batch.Transactions) > 0 {
+			if err := tx.CreateInBatches(batch.Transactions, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(batch.Products) > 0 {
+			if err := tx.CreateInBatches(batch.Products, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(batch.Records) > 0 {
+			if err := tx.CreateInBatches(batch.Records, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save legacy import: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetImport(ctx context.Context, festivalID, id uuid.UUID) (*Import, error) {
+	var imp Import
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&imp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get legacy import: %w", err)
+	}
+	return &imp, nil
+}
+
+func (r *repository) ListImports(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Import, int64, error) {
+	var imports []Import
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&Import{}).Where("festival_id = ?", festivalID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count legacy imports: %w", err)
+	}
+	// The row errors are left out of the list, they are read with the import
+	err := db.Omit("errors").Order("created_at DESC").Offset(offset).Limit(limit).Find(&imports).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list legacy imports: %w", err)
+	}
+	return imports, total, nil
+}
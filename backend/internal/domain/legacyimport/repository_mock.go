@@ -0,0 +1,58 @@
+package legacyimport
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	args := m.Called(ctx, festivalID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRepository) ListImported(ctx context.Context, festivalID uuid.UUID, source string, kind Kind, externalIDs []string) (map[string]uuid.UUID, error) {
+	args := m.Called(ctx, festivalID, source, kind, externalIDs)
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) ListUsers(ctx context.Context, festivalID uuid.UUID, emails []string) (map[string]User, error) {
+	args := m.Called(ctx, festivalID, emails)
+	return args.Get(0).(map[string]User), args.Error(1)
+}
+
+func (m *MockRepository) ListStands(ctx context.Context, festivalID uuid.UUID) (map[string]uuid.UUID, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) SaveBatch(ctx context.Context, batch *Batch) error {
+	args := m.Called(ctx, batch)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetImport(ctx context.Context, festivalID, id uuid.UUID) (*Import, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Import), args.Error(1)
+}
+
+func (m *MockRepository) ListImports(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Import, int64, error) {
+	args := m.Called(ctx, festivalID, offset, limit)
+	return args.Get(0).([]Import), args.Get(1).(int64), args.Error(2)
+}
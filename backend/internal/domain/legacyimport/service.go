@@ -0,0 +1,412 @@
+package legacyimport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+)
+
+var sourcePattern = regexp.MustCompile(`^[a-z0-9_-]{2,50}$`)
+
+type Service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+// plan is what an import would create, filled row by row
+type plan struct {
+	festivalID   uuid.UUID
+	importID     uuid.UUID
+	source       string
+	kind         Kind
+	unit         AmountUnit
+	loc          *time.Location
+	imported     map[string]uuid.UUID
+	batch        Batch
+	errors       RowErrors
+	totalAmount  int64
+	alreadyCount int
+}
+
+func (p *plan) reject(row Row, reason string) {
+	p.errors = append(p.errors, RowError{Line: row.Line, ExternalID: row.ExternalID, Reason: reason})
+}
+
+func (p *plan) record(row Row, entityID uuid.UUID) {
+	p.batch.Records = append(p.batch.Records, Record{
+		FestivalID: p.festivalID,
+		Source:     p.source,
+		Kind:       p.kind,
+		ExternalID: row.ExternalID,
+		EntityID:   entityID,
+		ImportID:   p.importID,
+	})
+}
+
+// reference is the reference of a record created for a row, unique per source
+func (p *plan) reference(row Row) string {
+	return "legacy:" + p.source + ":" + row.ExternalID
+}
+
+// Run imports a CSV export of a previous cashless provider. Rows imported by an earlier
+// run of the same source are skipped and invalid rows are reported, the others are all
+// created in one transaction. A dry run validates the file and saves nothing.
+func (s *Service) Run(ctx context.Context, festivalID uuid.UUID, req ImportRequest, fileName string, file io.Reader, dryRun bool, createdBy *uuid.UUID) (*Result, error) {
+	if !req.Kind.IsValid() {
+		return nil, ErrInvalidKind
+	}
+	if req.AmountUnit == "" {
+		req.AmountUnit = AmountUnitDecimal
+	}
+	if !req.AmountUnit.IsValid() {
+		return nil, ErrInvalidUnit
+	}
+	source := strings.ToLower(strings.TrimSpace(req.Source))
+	if !sourcePattern.MatchString(source) {
+		return nil, ErrInvalidSource
+	}
+
+	timezone, err := s.repo.GetFestivalTimezone(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if timezone == "" {
+		return nil, ErrFestivalMissing
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	rows, rowErrors, err := Parse(file, req.Kind, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{
+		festivalID: festivalID,
+		importID:   uuid.New(),
+		source:     source,
+		kind:       req.Kind,
+		unit:       req.AmountUnit,
+		loc:        loc,
+		errors:     rowErrors,
+	}
+
+	externalIDs := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		externalIDs[row.ExternalID] = true
+	}
+	p.imported, err = s.repo.ListImported(ctx, festivalID, source, req.Kind, sortedKeys(externalIDs))
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		if _, ok := p.imported[row.ExternalID]; ok {
+			p.alreadyCount++
+			continue
+		}
+		pending = append(pending, row)
+	}
+
+	switch req.Kind {
+	case KindWallets:
+		err = s.planWallets(ctx, p, pending)
+	case KindTransactions:
+		err = s.planTransactions(ctx, p, pending)
+	case KindProducts:
+		err = s.planProducts(ctx, p, pending)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		DryRun:          dryRun,
+		Kind:            req.Kind,
+		Source:          source,
+		TotalRows:       len(rows) + len(rowErrors),
+		Imported:        len(p.batch.Records),
+		AlreadyImported: p.alreadyCount,
+		Invalid:         len(p.errors),
+		TotalAmount:     p.totalAmount,
+		Errors:          p.errors,
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	stored := p.errors
+	if len(stored) > maxStoredErrors {
+		stored = stored[:maxStoredErrors]
+	}
+	p.batch.Import = &Import{
+		ID:              p.importID,
+		FestivalID:      festivalID,
+		Source:          source,
+		Kind:            req.Kind,
+		FileName:        fileName,
+		TotalRows:       result.TotalRows,
+		Imported:        result.Imported,
+		AlreadyImported: result.AlreadyImported,
+		Invalid:         result.Invalid,
+		TotalAmount:     result.TotalAmount,
+		Errors:          stored,
+		CreatedBy:       createdBy,
+		CreatedAt:       s.now(),
+	}
+	if err := s.repo.SaveBatch(ctx, &p.batch); err != nil {
+		return nil, err
+	}
+	result.ImportID = &p.importID
+	return result, nil
+}
+
+// planWallets creates a wallet per row with its balance, recorded by an opening
+// adjustment so the ledger of the wallet matches it
+func (s *Service) planWallets(ctx context.Context, p *plan, rows []Row) error {
+	emails := map[string]bool{}
+	for _, row := range rows {
+		if email := strings.ToLower(row.Values["email"]); email != "" {
+			emails[email] = true
+		}
+	}
+	users, err := s.repo.ListUsers(ctx, p.festivalID, sortedKeys(emails))
+	if err != nil {
+		return err
+	}
+
+	linked := map[uuid.UUID]bool{}
+	for _, row := range rows {
+		balance, err := parseAmount(row.Values["balance"], p.unit)
+		if err != nil {
+			p.reject(row, "invalid balance")
+			continue
+		}
+		if balance < 0 {
+			p.reject(row, "negative balance")
+			continue
+		}
+		createdAt, ok := p.optionalTime(row, s.now())
+		if !ok {
+			continue
+		}
+
+		w := wallet.Wallet{
+			ID:         uuid.New(),
+			FestivalID: p.festivalID,
+			Balance:    balance,
+			Status:     wallet.WalletStatusActive,
+			ImportID:   &p.importID,
+			CreatedAt:  createdAt,
+			UpdatedAt:  s.now(),
+		}
+		if isClosedStatus(row.Values["status"]) {
+			w.Status = wallet.WalletStatusClosed
+		}
+		if email := strings.ToLower(row.Values["email"]); email != "" {
+			if user, ok := users[email]; ok {
+				if user.HasWallet || linked[user.ID] {
+					p.reject(row, "account already has a wallet in the festival")
+					continue
+				}
+				linked[user.ID] = true
+				w.UserID = &user.ID
+				w.ClaimedAt = &createdAt
+			}
+		}
+
+		p.batch.Wallets = append(p.batch.Wallets, w)
+		if balance > 0 {
+			p.batch.Transactions = append(p.batch.Transactions, wallet.Transaction{
+				ID:           uuid.New(),
+				WalletID:     w.ID,
+				Type:         wallet.TransactionTypeAdjustment,
+				Amount:       balance,
+				BalanceAfter: balance,
+				Reference:    p.reference(row),
+				Metadata:     wallet.TransactionMeta{Description: "Opening balance imported from " + p.source},
+				Status:       wallet.TransactionStatusCompleted,
+				ImportID:     &p.importID,
+				CreatedAt:    createdAt,
+			})
+		}
+		p.totalAmount += balance
+		p.record(row, w.ID)
+	}
+	return nil
+}
+
+// planTransactions creates the history of wallets imported before from the same source.
+// The transactions are IMPORTED, they never move the balances, which the wallets brought.
+func (s *Service) planTransactions(ctx context.Context, p *plan, rows []Row) error {
+	walletIDs := map[string]bool{}
+	for _, row := range rows {
+		walletIDs[row.Values["wallet_external_id"]] = true
+	}
+	wallets, err := s.repo.ListImported(ctx, p.festivalID, p.source, KindWallets, sortedKeys(walletIDs))
+	if err != nil {
+		return err
+	}
+	stands, err := s.repo.ListStands(ctx, p.festivalID)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		walletID, ok := wallets[row.Values["wallet_external_id"]]
+		if !ok {
+			p.reject(row, "wallet not imported from this source")
+			continue
+		}
+		txType, ok := parseTransactionType(row.Values["type"])
+		if !ok {
+			p.reject(row, fmt.Sprintf("unknown transaction type %q", row.Values["type"]))
+			continue
+		}
+		amount, err := parseAmount(row.Values["amount"], p.unit)
+		if err != nil {
+			p.reject(row, "invalid amount")
+			continue
+		}
+		if amount < 0 {
+			amount = -amount
+		}
+		if isDebit(txType) {
+			amount = -amount
+		}
+		createdAt, err := parseTime(row.Values["created_at"], p.loc)
+		if err != nil {
+			p.reject(row, "invalid date")
+			continue
+		}
+
+		tx := wallet.Transaction{
+			ID:        uuid.New(),
+			WalletID:  walletID,
+			Type:      txType,
+			Amount:    amount,
+			Reference: p.reference(row),
+			Metadata:  wallet.TransactionMeta{Description: "Imported from " + p.source},
+			Status:    wallet.TransactionStatusImported,
+			ImportID:  &p.importID,
+			CreatedAt: createdAt,
+		}
+		if ref := row.Values["reference"]; ref != "" {
+			tx.Metadata.Description += " (" + ref + ")"
+		}
+		if raw := row.Values["balance_after"]; raw != "" {
+			after, err := parseAmount(raw, p.unit)
+			if err != nil {
+				p.reject(row, "invalid balance after")
+				continue
+			}
+			tx.BalanceAfter = after
+			tx.BalanceBefore = after - amount
+		}
+		if name := row.Values["stand"]; name != "" {
+			if standID, ok := stands[strings.ToLower(name)]; ok {
+				tx.StandID = &standID
+			} else {
+				tx.Metadata.Location = name
+			}
+		}
+
+		p.batch.Transactions = append(p.batch.Transactions, tx)
+		p.totalAmount += amount
+		p.record(row, tx.ID)
+	}
+	return nil
+}
+
+// planProducts creates the products of the stands of the festival, matched by name
+func (s *Service) planProducts(ctx context.Context, p *plan, rows []Row) error {
+	stands, err := s.repo.ListStands(ctx, p.festivalID)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		standID, ok := stands[strings.ToLower(row.Values["stand"])]
+		if !ok {
+			p.reject(row, fmt.Sprintf("unknown stand %q", row.Values["stand"]))
+			continue
+		}
+		name := row.Values["name"]
+		if name == "" {
+			p.reject(row, "no product name")
+			continue
+		}
+		price, err := parseAmount(row.Values["price"], p.unit)
+		if err != nil {
+			p.reject(row, "invalid price")
+			continue
+		}
+		if price < 0 {
+			p.reject(row, "negative price")
+			continue
+		}
+
+		prod := product.Product{
+			ID:        uuid.New(),
+			StandID:   standID,
+			Name:      name,
+			Price:     price,
+			Category:  parseCategory(row.Values["category"]),
+			SKU:       row.Values["sku"],
+			Status:    product.ProductStatusActive,
+			Tags:      []string{},
+			ImportID:  &p.importID,
+			CreatedAt: s.now(),
+			UpdatedAt: s.now(),
+		}
+		p.batch.Products = append(p.batch.Products, prod)
+		p.totalAmount += price
+		p.record(row, prod.ID)
+	}
+	return nil
+}
+
+// optionalTime reads the created_at of a row, fallback when it is empty. It rejects the
+// row and returns false when the date is invalid.
+func (p *plan) optionalTime(row Row, fallback time.Time) (time.Time, bool) {
+	raw := row.Values["created_at"]
+	if raw == "" {
+		return fallback, true
+	}
+	t, err := parseTime(raw, p.loc)
+	if err != nil {
+		p.reject(row, "invalid date")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Get returns an import with its row errors
+func (s *Service) Get(ctx context.Context, festivalID, id uuid.UUID) (*Import, error) {
+	imp, err := s.repo.GetImport(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp == nil {
+		return nil, ErrImportNotFound
+	}
+	return imp, nil
+}
+
+// List returns the imports of a festival, latest first, without their row errors
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Import, int64, error) {
+	return s.repo.ListImports(ctx, festivalID, offset, limit)
+}
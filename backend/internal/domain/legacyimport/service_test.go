@@ -0,0 +1,216 @@
+package legacyimport
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(repo Repository) *Service {
+	service := NewService(repo)
+	service.now = func() time.Time { return time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC) }
+	return service
+}
+
+// expectTimezone returns the timezone of the festival from the repository
+func expectTimezone(mockRepo *MockRepository, festivalID uuid.UUID) {
+	mockRepo.On("GetFestivalTimezone", mock.Anything, festivalID).Return("Europe/Brussels", nil)
+}
+
+// expectSaveBatch returns the batch the next run saves
+func expectSaveBatch(mockRepo *MockRepository) *Batch {
+	saved := &Batch{}
+	mockRepo.On("SaveBatch", mock.Anything, mock.AnythingOfType("*legacyimport.Batch")).
+		Run(func(args mock.Arguments) { *saved = *args.Get(1).(*Batch) }).
+		Return(nil).Once()
+	return saved
+}
+
+func TestParse_AliasesAndColumnMap(t *testing.T) {
+	file := "\ufeffChip ID;Customer Email;Remaining Balance\n" +
+		"A1;Ann@example.com;12,50\n" +
+		";bob@example.com;3\n" +
+		"A1;carl@example.com;1\n"
+
+	rows, errs, err := Parse(strings.NewReader(file), KindWallets, nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "A1", rows[0].ExternalID)
+	assert.Equal(t, "12,50", rows[0].Values["balance"])
+	require.Len(t, errs, 2)
+	assert.Equal(t, 3, errs[0].Line)
+	assert.Equal(t, "external ID already on line 2", errs[1].Reason)
+
+	_, _, err = Parse(strings.NewReader("Card,Credit left\nA1,3\n"), KindWallets, nil)
+	assert.ErrorIs(t, err, ErrMissingColumns)
+
+	rows, _, err = Parse(strings.NewReader("Card,Credit left\nA1,3\n"), KindWallets, map[string]string{"external_id": "Card", "balance": "Credit left"})
+	require.NoError(t, err)
+	assert.Equal(t, "3", rows[0].Values["balance"])
+
+	_, _, err = Parse(strings.NewReader("Card\nA1\n"), KindWallets, map[string]string{"iban": "Card"})
+	assert.ErrorIs(t, err, ErrInvalidColumns)
+}
+
+func TestParseAmount(t *testing.T) {
+	for raw, want := range map[string]int64{"12.50": 1250, "12,5": 1250, "1.234,56": 123456, "€ 3": 300, "-2.10": -210} {
+		amount, err := parseAmount(raw, AmountUnitDecimal)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, amount, raw)
+	}
+	amount, err := parseAmount("1250", AmountUnitCents)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1250), amount)
+	_, err = parseAmount("12.50", AmountUnitCents)
+	assert.Error(t, err)
+}
+
+func TestService_ImportWalletsIsIdempotent(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	expectTimezone(mockRepo, festivalID)
+	ann := User{ID: uuid.New(), Email: "ann@example.com"}
+	dan := User{ID: uuid.New(), Email: "dan@example.com", HasWallet: true}
+	req := ImportRequest{Kind: KindWallets, Source: "Acme-Cashless"}
+	file := "wallet_id,email,balance,status,created_at\n" +
+		"W1,Ann@example.com,12.50,active,2026-06-01 10:00\n" +
+		"W2,,0,blocked,\n" +
+		"W3,dan@example.com,5,,\n" +
+		"W4,,-1,,\n"
+	ctx := context.Background()
+
+	walletIDs := []string{"W1", "W2", "W3", "W4"}
+	mockRepo.On("ListImported", mock.Anything, festivalID, "acme-cashless", KindWallets, walletIDs).Return(map[string]uuid.UUID{}, nil).Twice()
+	mockRepo.On("ListUsers", mock.Anything, festivalID, []string{"ann@example.com", "dan@example.com"}).
+		Return(map[string]User{ann.Email: ann, dan.Email: dan}, nil).Twice()
+
+	preview, err := service.Run(ctx, festivalID, req, "wallets.csv", strings.NewReader(file), true, nil)
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.Nil(t, preview.ImportID)
+	assert.Equal(t, "acme-cashless", preview.Source)
+	assert.Equal(t, 4, preview.TotalRows)
+	assert.Equal(t, 2, preview.Imported)
+	assert.Equal(t, 2, preview.Invalid)
+	assert.Equal(t, int64(1250), preview.TotalAmount)
+	mockRepo.AssertNotCalled(t, "SaveBatch", mock.Anything, mock.Anything)
+
+	batch := expectSaveBatch(mockRepo)
+	result, err := service.Run(ctx, festivalID, req, "wallets.csv", strings.NewReader(file), false, nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.ImportID)
+	require.Len(t, batch.Wallets, 2)
+	assert.Equal(t, &ann.ID, batch.Wallets[0].UserID)
+	assert.Equal(t, result.ImportID, batch.Wallets[0].ImportID)
+	brussels, _ := time.LoadLocation("Europe/Brussels")
+	assert.True(t, batch.Wallets[0].CreatedAt.Equal(time.Date(2026, 6, 1, 10, 0, 0, 0, brussels)))
+	assert.Nil(t, batch.Wallets[1].UserID)
+	assert.Equal(t, wallet.WalletStatusClosed, batch.Wallets[1].Status)
+	// Only the non-zero balance has an opening adjustment
+	require.Len(t, batch.Transactions, 1)
+	assert.Equal(t, wallet.TransactionTypeAdjustment, batch.Transactions[0].Type)
+	assert.Equal(t, int64(1250), batch.Transactions[0].BalanceAfter)
+	assert.Equal(t, "legacy:acme-cashless:W1", batch.Transactions[0].Reference)
+	require.NotNil(t, batch.Import)
+	assert.Equal(t, *result.ImportID, batch.Import.ID)
+	assert.Equal(t, 2, batch.Import.Invalid)
+
+	// Running the file again only reports the rows imported before
+	imported := map[string]uuid.UUID{}
+	for _, record := range batch.Records {
+		imported[record.ExternalID] = record.EntityID
+	}
+	require.Len(t, imported, 2)
+	mockRepo.On("ListImported", mock.Anything, festivalID, "acme-cashless", KindWallets, walletIDs).Return(imported, nil).Once()
+	mockRepo.On("ListUsers", mock.Anything, festivalID, []string{"dan@example.com"}).Return(map[string]User{dan.Email: dan}, nil).Once()
+	batch = expectSaveBatch(mockRepo)
+	again, err := service.Run(ctx, festivalID, req, "wallets.csv", strings.NewReader(file), false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.AlreadyImported)
+	assert.Zero(t, again.Imported)
+	assert.Empty(t, batch.Wallets)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ImportTransactionsAndProducts(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	expectTimezone(mockRepo, festivalID)
+	barID := uuid.New()
+	mockRepo.On("ListStands", mock.Anything, festivalID).Return(map[string]uuid.UUID{"bar nord": barID}, nil).Twice()
+	walletID := uuid.New()
+	ctx := context.Background()
+
+	file := "transaction_id,wallet_id,type,amount,balance_after,stand,date\n" +
+		"T1,W1,Reload,2000,2000,,2026-06-01T10:00:00Z\n" +
+		"T2,W1,sale,450,1550,Bar Nord,2026-06-01T11:00:00Z\n" +
+		"T3,W1,sale,300,,Old Tent,2026-06-01T12:00:00Z\n" +
+		"T4,W9,sale,100,,,2026-06-01T12:00:00Z\n" +
+		"T5,W1,gift,100,,,2026-06-01T12:00:00Z\n"
+	mockRepo.On("ListImported", mock.Anything, festivalID, "acme", KindTransactions, []string{"T1", "T2", "T3", "T4", "T5"}).Return(map[string]uuid.UUID{}, nil).Once()
+	mockRepo.On("ListImported", mock.Anything, festivalID, "acme", KindWallets, []string{"W1", "W9"}).Return(map[string]uuid.UUID{"W1": walletID}, nil).Once()
+	batch := expectSaveBatch(mockRepo)
+	result, err := service.Run(ctx, festivalID, ImportRequest{Kind: KindTransactions, Source: "acme", AmountUnit: AmountUnitCents}, "tx.csv", strings.NewReader(file), false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, 2, result.Invalid)
+	assert.Equal(t, int64(1250), result.TotalAmount)
+
+	txs := batch.Transactions
+	require.Len(t, txs, 3)
+	for _, tx := range txs {
+		assert.Equal(t, wallet.TransactionStatusImported, tx.Status)
+		assert.Equal(t, walletID, tx.WalletID)
+	}
+	assert.Equal(t, wallet.TransactionTypeTopUp, txs[0].Type)
+	assert.Equal(t, int64(-450), txs[1].Amount)
+	assert.Equal(t, int64(2000), txs[1].BalanceBefore)
+	assert.Equal(t, &barID, txs[1].StandID)
+	assert.Nil(t, txs[2].StandID)
+	assert.Equal(t, "Old Tent", txs[2].Metadata.Location)
+
+	file = "product_id,stand,name,price,category\n" +
+		"P1,Bar Nord,Pils,\"3,50\",Bière\n" +
+		"P2,Old Tent,Fries,4,food\n"
+	mockRepo.On("ListImported", mock.Anything, festivalID, "acme", KindProducts, []string{"P1", "P2"}).Return(map[string]uuid.UUID{}, nil).Once()
+	batch = expectSaveBatch(mockRepo)
+	result, err = service.Run(ctx, festivalID, ImportRequest{Kind: KindProducts, Source: "acme"}, "products.csv", strings.NewReader(file), false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Line)
+	products := batch.Products
+	require.Len(t, products, 1)
+	assert.Equal(t, int64(350), products[0].Price)
+	assert.Equal(t, product.ProductCategoryBeer, products[0].Category)
+	assert.Equal(t, barID, products[0].StandID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RunRejectsInvalidRequests(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+	festivalID := uuid.New()
+	expectTimezone(mockRepo, festivalID)
+
+	_, err := service.Run(ctx, festivalID, ImportRequest{Kind: "TICKETS", Source: "acme"}, "", strings.NewReader(""), true, nil)
+	assert.ErrorIs(t, err, ErrInvalidKind)
+	_, err = service.Run(ctx, festivalID, ImportRequest{Kind: KindWallets, Source: "acme cashless"}, "", strings.NewReader(""), true, nil)
+	assert.ErrorIs(t, err, ErrInvalidSource)
+	_, err = service.Run(ctx, festivalID, ImportRequest{Kind: KindWallets, Source: "acme", AmountUnit: "EUR"}, "", strings.NewReader(""), true, nil)
+	assert.ErrorIs(t, err, ErrInvalidUnit)
+	_, err = service.Run(ctx, festivalID, ImportRequest{Kind: KindWallets, Source: "acme"}, "", strings.NewReader(""), true, nil)
+	assert.ErrorIs(t, err, ErrInvalidCSV)
+	mockRepo.AssertNotCalled(t, "ListImported", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -81,7 +81,7 @@ func (h *Handler) CreateArtist(c *gin.Context) {
 
 	var req CreateArtistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -209,7 +209,7 @@ func (h *Handler) UpdateArtist(c *gin.Context) {
 
 	var req UpdateArtistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -308,7 +308,7 @@ func (h *Handler) CreateStage(c *gin.Context) {
 
 	var req CreateStageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -393,7 +393,7 @@ func (h *Handler) UpdateStage(c *gin.Context) {
 
 	var req UpdateStageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -495,7 +495,7 @@ func (h *Handler) CreatePerformance(c *gin.Context) {
 
 	var req CreatePerformanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -601,7 +601,7 @@ func (h *Handler) UpdatePerformance(c *gin.Context) {
 
 	var req UpdatePerformanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -675,7 +675,7 @@ func (h *Handler) UpdatePerformanceStatus(c *gin.Context) {
 		Status PerformanceStatus `json:"status" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
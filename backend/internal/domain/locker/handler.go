@@ -0,0 +1,592 @@
+package locker
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the management of zones and lockers, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	zones := r.Group("/locker-zones")
+	{
+		zones.POST("", h.CreateZone)
+		zones.PATCH("/:zoneId", h.UpdateZone)
+		zones.POST("/:zoneId/lockers", h.CreateLockers)
+	}
+	r.PATCH("/lockers/:lockerId", h.UpdateLocker)
+}
+
+// RegisterStaffRoutes registers the rental desk and the occupancy of the lockers,
+// which should be restricted to staff
+func (h *Handler) RegisterStaffRoutes(r *gin.RouterGroup) {
+	zones := r.Group("/locker-zones")
+	{
+		zones.GET("", h.ListZones)
+		zones.GET("/:zoneId", h.GetZone)
+		zones.GET("/:zoneId/lockers", h.ListLockers)
+	}
+	r.GET("/locker-occupancy", h.GetOccupancy)
+
+	rentals := r.Group("/locker-rentals")
+	{
+		rentals.GET("", h.ListRentals)
+		rentals.POST("", h.StartRental)
+		rentals.POST("/return", h.ReturnRental)
+		rentals.GET("/:rentalId", h.GetRental)
+		rentals.POST("/:rentalId/lost-ticket", h.ReleaseLostTicket)
+	}
+}
+
+// CreateZone adds a bank of lockers or a gear check
+// @Summary Create locker zone
+// @Description Add a bank of lockers or a gear check with the price of a rental and the fee for a lost ticket, in cents
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateZoneRequest true "Zone"
+// @Success 201 {object} response.Response{data=Zone} "Created zone"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-zones [post]
+func (h *Handler) CreateZone(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	zone, err := h.service.CreateZone(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, zone)
+}
+
+// ListZones lists the zones of the festival
+// @Summary List locker zones
+// @Description List the banks of lockers and gear checks of the festival
+// @Tags lockers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Zone} "Zones"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-zones [get]
+func (h *Handler) ListZones(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	zones, err := h.service.ListZones(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, zones)
+}
+
+// GetZone returns a zone
+// @Summary Get locker zone
+// @Description Get a bank of lockers or gear check of the festival
+// @Tags lockers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param zoneId path string true "Zone ID" format(uuid)
+// @Success 200 {object} response.Response{data=Zone} "Zone"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Zone not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-zones/{zoneId} [get]
+func (h *Handler) GetZone(c *gin.Context) {
+	festivalID, zoneID, ok := zoneParams(c)
+	if !ok {
+		return
+	}
+
+	zone, err := h.service.GetZone(c.Request.Context(), festivalID, zoneID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, zone)
+}
+
+// UpdateZone changes a zone
+// @Summary Update locker zone
+// @Description Change the name, location or prices of a zone, or close it with active=false. New prices apply to the rentals started afterwards; a closed zone takes no new rentals but its items can still be collected.
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param zoneId path string true "Zone ID" format(uuid)
+// @Param request body UpdateZoneRequest true "Changes"
+// @Success 200 {object} response.Response{data=Zone} "Updated zone"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Zone not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-zones/{zoneId} [patch]
+func (h *Handler) UpdateZone(c *gin.Context) {
+	festivalID, zoneID, ok := zoneParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	zone, err := h.service.UpdateZone(c.Request.Context(), festivalID, zoneID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, zone)
+}
+
+// CreateLockers adds lockers to a zone
+// @Summary Add lockers
+// @Description Add lockers or gear check slots to a zone, by number or as a range of count numbers from "from" with a prefix, zero padded to "padding" digits. Up to 500 at once; a number already used in the zone adds none of them.
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param zoneId path string true "Zone ID" format(uuid)
+// @Param request body CreateLockersRequest true "Lockers"
+// @Success 201 {object} response.Response{data=[]Locker} "Added lockers"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Zone not found"
+// @Failure 409 {object} response.ErrorResponse "Number already used"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-zones/{zoneId}/lockers [post]
+func (h *Handler) CreateLockers(c *gin.Context) {
+	festivalID, zoneID, ok := zoneParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateLockersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	lockers, err := h.service.CreateLockers(c.Request.Context(), festivalID, zoneID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, lockers)
+}
+
+// ListLockers lists the lockers of a zone
+// @Summary List lockers
+// @Description List the lockers of a zone in number order with their status
+// @Tags lockers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param zoneId path string true "Zone ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Locker} "Lockers"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Zone not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-zones/{zoneId}/lockers [get]
+func (h *Handler) ListLockers(c *gin.Context) {
+	festivalID, zoneID, ok := zoneParams(c)
+	if !ok {
+		return
+	}
+
+	lockers, err := h.service.ListLockers(c.Request.Context(), festivalID, zoneID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, lockers)
+}
+
+// UpdateLocker takes a locker out of service or back into service
+// @Summary Update locker
+// @Description Take a locker out of service, e.g. when it is broken, or back into service. Rented lockers cannot be changed.
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lockerId path string true "Locker ID" format(uuid)
+// @Param request body UpdateLockerRequest true "Status"
+// @Success 200 {object} response.Response{data=Locker} "Updated locker"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Locker not found"
+// @Failure 409 {object} response.ErrorResponse "Locker rented"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/lockers/{lockerId} [patch]
+func (h *Handler) UpdateLocker(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	lockerID, err := uuid.Parse(c.Param("lockerId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid locker ID", nil)
+		return
+	}
+
+	var req UpdateLockerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	locker, err := h.service.UpdateLocker(c.Request.Context(), festivalID, lockerID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, locker)
+}
+
+// GetOccupancy returns the use of the lockers of the festival
+// @Summary Get locker occupancy
+// @Description Get the lockers available, occupied and out of service in each zone and in total, with the rentals, lost tickets and revenue charged, for the operations dashboard
+// @Tags lockers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Occupancy} "Occupancy"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-occupancy [get]
+func (h *Handler) GetOccupancy(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	occupancy, err := h.service.GetOccupancy(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, occupancy)
+}
+
+// StartRental rents a locker to an attendee
+// @Summary Start locker rental
+// @Description Rent a locker of an open zone to the holder of a wallet, or the first available one when lockerId is left out, and charge the price of the zone to the wallet. The response holds the ticket code to hand to the attendee.
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body StartRentalRequest true "Rental"
+// @Success 201 {object} response.Response{data=Rental} "Started rental"
+// @Failure 400 {object} response.ErrorResponse "Zone closed, wallet not chargeable or insufficient balance"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Zone or wallet not found"
+// @Failure 409 {object} response.ErrorResponse "No locker available"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-rentals [post]
+func (h *Handler) StartRental(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req StartRentalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	rental, err := h.service.StartRental(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, rental)
+}
+
+// ReturnRental ends a rental with its ticket
+// @Summary Return locker rental
+// @Description End the rental a ticket code was issued for when the attendee collects their items, and free the locker. Case, spaces and dashes in the code are ignored.
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body ReturnRequest true "Ticket"
+// @Success 200 {object} response.Response{data=Rental} "Returned rental"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No active rental has the ticket code"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-rentals/return [post]
+func (h *Handler) ReturnRental(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req ReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	rental, err := h.service.ReturnRental(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rental)
+}
+
+// ReleaseLostTicket ends a rental whose ticket was lost
+// @Summary Release items without ticket
+// @Description End an active rental whose ticket was lost, once staff checked that the attendee owns the items. The lost ticket fee of the zone is charged to the wallet of the rental and the override is recorded in the audit log with the reason.
+// @Tags lockers
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param rentalId path string true "Rental ID" format(uuid)
+// @Param request body LostTicketRequest true "How ownership was checked"
+// @Success 200 {object} response.Response{data=Rental} "Released rental"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or insufficient balance"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Rental not found"
+// @Failure 409 {object} response.ErrorResponse "Rental already ended"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-rentals/{rentalId}/lost-ticket [post]
+func (h *Handler) ReleaseLostTicket(c *gin.Context) {
+	festivalID, rentalID, ok := rentalParams(c)
+	if !ok {
+		return
+	}
+
+	var req LostTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	rental, err := h.service.ReleaseLostTicket(c.Request.Context(), festivalID, rentalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rental)
+}
+
+// GetRental returns a rental
+// @Summary Get locker rental
+// @Description Get a locker rental of the festival
+// @Tags lockers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param rentalId path string true "Rental ID" format(uuid)
+// @Success 200 {object} response.Response{data=Rental} "Rental"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Rental not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-rentals/{rentalId} [get]
+func (h *Handler) GetRental(c *gin.Context) {
+	festivalID, rentalID, ok := rentalParams(c)
+	if !ok {
+		return
+	}
+
+	rental, err := h.service.GetRental(c.Request.Context(), festivalID, rentalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rental)
+}
+
+// ListRentals lists the rentals of the festival
+// @Summary List locker rentals
+// @Description List the locker rentals of the festival, latest first, e.g. the active rentals of a wallet when an attendee lost their ticket
+// @Tags lockers
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param zoneId query string false "Only rentals of this zone" format(uuid)
+// @Param walletId query string false "Only rentals of this wallet" format(uuid)
+// @Param status query string false "Only rentals in this status" Enums(ACTIVE, RETURNED, LOST_TICKET, CANCELLED)
+// @Param lockerNumber query string false "Only rentals of lockers with this number"
+// @Param limit query int false "Maximum rentals returned" default(100)
+// @Success 200 {object} response.Response{data=[]Rental} "Rentals"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/locker-rentals [get]
+func (h *Handler) ListRentals(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	filter := RentalFilter{LockerNumber: c.Query("lockerNumber"), Limit: 100}
+	if raw := c.Query("zoneId"); raw != "" {
+		zoneID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid zone ID", nil)
+			return
+		}
+		filter.ZoneID = &zoneID
+	}
+	if raw := c.Query("walletId"); raw != "" {
+		walletID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid wallet ID", nil)
+			return
+		}
+		filter.WalletID = &walletID
+	}
+	if raw := c.Query("status"); raw != "" {
+		status := RentalStatus(raw)
+		switch status {
+		case RentalStatusActive, RentalStatusReturned, RentalStatusLostTicket, RentalStatusCancelled:
+			filter.Status = &status
+		default:
+			response.BadRequest(c, "INVALID_STATUS", "Status must be ACTIVE, RETURNED, LOST_TICKET or CANCELLED", nil)
+			return
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 1000 {
+			response.BadRequest(c, "INVALID_LIMIT", "Limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	rentals, err := h.service.ListRentals(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rentals)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func zoneParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	zoneID, err := uuid.Parse(c.Param("zoneId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid zone ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, zoneID, true
+}
+
+func rentalParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	rentalID, err := uuid.Parse(c.Param("rentalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid rental ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, rentalID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrZoneNotFound):
+		response.NotFound(c, "Locker zone not found")
+	case errors.Is(err, ErrLockerNotFound):
+		response.NotFound(c, "Locker not found")
+	case errors.Is(err, ErrRentalNotFound):
+		response.NotFound(c, "Locker rental not found")
+	case errors.Is(err, ErrWalletNotFound):
+		response.NotFound(c, "Wallet not found")
+	case errors.Is(err, ErrInvalidTicket):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrZoneClosed):
+		response.BadRequest(c, "ZONE_CLOSED", err.Error(), nil)
+	case errors.Is(err, ErrInvalidLockers):
+		response.BadRequest(c, "INVALID_LOCKERS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidStatus):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrInsufficientBalance):
+		response.BadRequest(c, "INSUFFICIENT_BALANCE", err.Error(), nil)
+	case errors.Is(err, ErrWalletNotActive):
+		response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+	case errors.Is(err, ErrChargeFailed):
+		response.BadRequest(c, "CHARGE_FAILED", err.Error(), nil)
+	case errors.Is(err, ErrNoLockerAvailable):
+		response.Conflict(c, "NO_LOCKER_AVAILABLE", err.Error())
+	case errors.Is(err, ErrLockerUnavailable):
+		response.Conflict(c, "LOCKER_UNAVAILABLE", err.Error())
+	case errors.Is(err, ErrLockerRented):
+		response.Conflict(c, "LOCKER_RENTED", err.Error())
+	case errors.Is(err, ErrNumberTaken):
+		response.Conflict(c, "LOCKER_NUMBER_TAKEN", err.Error())
+	case errors.Is(err, ErrRentalEnded):
+		response.Conflict(c, "RENTAL_ENDED", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,202 @@
+package locker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Locker errors
+var (
+	ErrZoneNotFound        = errors.New("locker zone not found")
+	ErrLockerNotFound      = errors.New("locker not found")
+	ErrRentalNotFound      = errors.New("locker rental not found")
+	ErrZoneClosed          = errors.New("locker zone is closed")
+	ErrNoLockerAvailable   = errors.New("no locker is available in the zone")
+	ErrLockerUnavailable   = errors.New("locker is not available")
+	ErrLockerRented        = errors.New("locker is rented; end the rental first")
+	ErrNumberTaken         = errors.New("another locker of the zone has this number")
+	ErrInvalidLockers      = errors.New("invalid locker numbers")
+	ErrInvalidStatus       = errors.New("status must be AVAILABLE or OUT_OF_SERVICE")
+	ErrWalletNotFound      = errors.New("wallet not found")
+	ErrInsufficientBalance = errors.New("insufficient wallet balance for the rental")
+	ErrWalletNotActive     = errors.New("wallet is not active")
+	ErrChargeFailed        = errors.New("rental could not be charged")
+	ErrRentalEnded         = errors.New("rental has already ended")
+	ErrInvalidTicket       = errors.New("no active rental has this ticket code")
+)
+
+// MaxLockersPerRequest bounds the lockers added to a zone at once
+const MaxLockersPerRequest = 500
+
+// ZoneKind is how the items of a zone are kept
+type ZoneKind string
+
+const (
+	ZoneKindLocker    ZoneKind = "LOCKER"     // Self-service lockers
+	ZoneKindGearCheck ZoneKind = "GEAR_CHECK" // Staffed cloakroom or gear check, one slot per item
+)
+
+// Zone is a bank of lockers or a gear check, with the price of a rental there
+type Zone struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID    uuid.UUID `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Name          string    `json:"name" gorm:"not null"`
+	Kind          ZoneKind  `json:"kind" gorm:"not null"`
+	Location      string    `json:"location,omitempty"`            // Where attendees find it, e.g. "Main entrance"
+	Price         int64     `json:"price" gorm:"not null"`         // Charged to the wallet when a rental starts, in cents
+	LostTicketFee int64     `json:"lostTicketFee" gorm:"not null"` // Charged when staff release the items without the ticket
+	Active        bool      `json:"active" gorm:"not null"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (Zone) TableName() string {
+	return "locker_zones"
+}
+
+// LockerStatus is whether a locker can be rented
+type LockerStatus string
+
+const (
+	LockerStatusAvailable    LockerStatus = "AVAILABLE"
+	LockerStatusOccupied     LockerStatus = "OCCUPIED" // Set and cleared by rentals only
+	LockerStatusOutOfService LockerStatus = "OUT_OF_SERVICE"
+)
+
+// Locker is a locker of a zone, or a numbered slot of a gear check
+type Locker struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ZoneID     uuid.UUID    `json:"zoneId" gorm:"type:uuid;not null"`
+	Number     string       `json:"number" gorm:"not null"` // Printed number, unique within the zone
+	Status     LockerStatus `json:"status" gorm:"not null"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+}
+
+func (Locker) TableName() string {
+	return "lockers"
+}
+
+// RentalStatus is the state of a rental
+type RentalStatus string
+
+const (
+	RentalStatusActive     RentalStatus = "ACTIVE"
+	RentalStatusReturned   RentalStatus = "RETURNED"    // Items collected with the ticket
+	RentalStatusLostTicket RentalStatus = "LOST_TICKET" // Items released by staff without the ticket
+	RentalStatusCancelled  RentalStatus = "CANCELLED"   // The wallet could not be charged
+)
+
+// Rental is the use of a locker by an attendee, paid from their wallet. The attendee
+// gets the ticket code to collect their items with.
+type Rental struct {
+	ID               uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID       uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ZoneID           uuid.UUID    `json:"zoneId" gorm:"type:uuid;not null"`
+	LockerID         uuid.UUID    `json:"lockerId" gorm:"type:uuid;not null"`
+	LockerNumber     string       `json:"lockerNumber" gorm:"not null"`
+	WalletID         uuid.UUID    `json:"walletId" gorm:"type:uuid;not null"`
+	TicketCode       string       `json:"ticketCode" gorm:"not null"`
+	Status           RentalStatus `json:"status" gorm:"not null"`
+	Amount           int64        `json:"amount" gorm:"not null"` // Rental price charged, in cents
+	TransactionID    *uuid.UUID   `json:"transactionId,omitempty" gorm:"type:uuid"`
+	LostTicketFee    int64        `json:"lostTicketFee" gorm:"not null"`
+	FeeTransactionID *uuid.UUID   `json:"feeTransactionId,omitempty" gorm:"type:uuid"`
+	OverrideReason   string       `json:"overrideReason,omitempty"` // How staff checked the attendee owned the items
+	StartedBy        *uuid.UUID   `json:"startedBy,omitempty" gorm:"type:uuid"`
+	EndedBy          *uuid.UUID   `json:"endedBy,omitempty" gorm:"type:uuid"`
+	StartedAt        time.Time    `json:"startedAt" gorm:"not null"`
+	EndedAt          *time.Time   `json:"endedAt,omitempty"`
+}
+
+func (Rental) TableName() string {
+	return "locker_rentals"
+}
+
+// RentalFilter narrows the listed rentals
+type RentalFilter struct {
+	ZoneID       *uuid.UUID
+	WalletID     *uuid.UUID
+	Status       *RentalStatus
+	LockerNumber string
+	Limit        int
+}
+
+// ZoneOccupancy is the use of the lockers of a zone
+type ZoneOccupancy struct {
+	ZoneID           uuid.UUID `json:"zoneId"`
+	Name             string    `json:"name"`
+	Kind             ZoneKind  `json:"kind"`
+	Active           bool      `json:"active"`
+	Lockers          int64     `json:"lockers"`
+	Available        int64     `json:"available"`
+	Occupied         int64     `json:"occupied"`
+	OutOfService     int64     `json:"outOfService"`
+	OccupancyPercent float64   `json:"occupancyPercent"` // Occupied lockers over the lockers in service
+	Rentals          int64     `json:"rentals"`          // Rentals charged, ended or not
+	LostTickets      int64     `json:"lostTickets"`
+	Revenue          int64     `json:"revenue"` // Rental prices and lost ticket fees charged, in cents
+}
+
+// Occupancy is the use of the lockers of a festival for the operations dashboard
+type Occupancy struct {
+	FestivalID  uuid.UUID       `json:"festivalId"`
+	Totals      ZoneOccupancy   `json:"totals"`
+	Zones       []ZoneOccupancy `json:"zones"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// CreateZoneRequest adds a bank of lockers or a gear check
+type CreateZoneRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Kind          ZoneKind `json:"kind" binding:"required,oneof=LOCKER GEAR_CHECK"`
+	Location      string   `json:"location,omitempty" binding:"max=255"`
+	Price         int64    `json:"price" binding:"min=0"`
+	LostTicketFee int64    `json:"lostTicketFee" binding:"min=0"`
+}
+
+// UpdateZoneRequest changes a zone; new prices apply to the rentals started afterwards
+type UpdateZoneRequest struct {
+	Name          *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Location      *string `json:"location,omitempty" binding:"omitempty,max=255"`
+	Price         *int64  `json:"price,omitempty" binding:"omitempty,min=0"`
+	LostTicketFee *int64  `json:"lostTicketFee,omitempty" binding:"omitempty,min=0"`
+	Active        *bool   `json:"active,omitempty"`
+}
+
+// CreateLockersRequest adds lockers to a zone, either by number or as a numbered range
+// such as A-001 to A-120
+type CreateLockersRequest struct {
+	Numbers []string `json:"numbers,omitempty" binding:"omitempty,max=500,dive,required,max=20"`
+	Prefix  string   `json:"prefix,omitempty" binding:"max=10"`
+	From    int      `json:"from,omitempty" binding:"omitempty,min=0"`
+	Count   int      `json:"count,omitempty" binding:"omitempty,min=1,max=500"`
+	Padding int      `json:"padding,omitempty" binding:"omitempty,min=1,max=6"`
+}
+
+// UpdateLockerRequest takes a locker out of service or back into service
+type UpdateLockerRequest struct {
+	Status LockerStatus `json:"status" binding:"required"`
+}
+
+// StartRentalRequest rents a locker of a zone to the holder of a wallet. Without a
+// locker the first available one of the zone is given.
+type StartRentalRequest struct {
+	ZoneID   uuid.UUID  `json:"zoneId" binding:"required"`
+	LockerID *uuid.UUID `json:"lockerId,omitempty"`
+	WalletID uuid.UUID  `json:"walletId" binding:"required"`
+}
+
+// ReturnRequest ends a rental when the attendee collects their items with the ticket
+type ReturnRequest struct {
+	TicketCode string `json:"ticketCode" binding:"required"`
+}
+
+// LostTicketRequest ends a rental whose ticket was lost, once staff checked that the
+// attendee owns the items, e.g. by their wallet or an ID and a description
+type LostTicketRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
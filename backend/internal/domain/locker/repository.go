@@ -0,0 +1,305 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// errTicketTaken is returned when another active rental has the ticket code
+var errTicketTaken = errors.New("ticket code is taken")
+
+type Repository interface {
+	CreateZone(ctx context.Context, zone *Zone) error
+	UpdateZone(ctx context.Context, zone *Zone) error
+	GetZone(ctx context.Context, festivalID, id uuid.UUID) (*Zone, error)
+	ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error)
+
+	CreateLockers(ctx context.Context, lockers []Locker) error
+	GetLocker(ctx context.Context, festivalID, id uuid.UUID) (*Locker, error)
+	ListLockers(ctx context.Context, festivalID, zoneID uuid.UUID) ([]Locker, error)
+	SetLockerStatus(ctx context.Context, locker *Locker, status LockerStatus) error
+
+	StartRental(ctx context.Context, rental *Rental) error
+	UpdateRental(ctx context.Context, rental *Rental) error
+	EndRental(ctx context.Context, rental *Rental) error
+	GetRental(ctx context.Context, festivalID, id uuid.UUID) (*Rental, error)
+	GetActiveRentalByTicket(ctx context.Context, festivalID uuid.UUID, ticketCode string) (*Rental, error)
+	ListRentals(ctx context.Context, festivalID uuid.UUID, filter RentalFilter) ([]Rental, error)
+
+	GetOccupancy(ctx context.Context, festivalID uuid.UUID) ([]ZoneOccupancy, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateZone(ctx context.Context, zone *Zone) error {
+	if err := r.db.WithContext(ctx).Create(zone).Error; err != nil {
+		return fmt.Errorf("failed to create locker zone: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateZone(ctx context.Context, zone *Zone) error {
+	if err := r.db.WithContext(ctx).Save(zone).Error; err != nil {
+		return fmt.Errorf("failed to update locker zone: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetZone(ctx context.Context, festivalID, id uuid.UUID) (*Zone, error) {
+	var zone Zone
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&zone).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get locker zone: %w", err)
+	}
+	return &zone, nil
+}
+
+func (r *repository) ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error) {
+	var zones []Zone
+	if err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).Order("name").Find(&zones).Error; err != nil {
+		return nil, fmt.Errorf("failed to list locker zones: %w", err)
+	}
+	return zones, nil
+}
+
+// CreateLockers adds lockers in one transaction; a number already used in the zone
+// adds none of them
+func (r *repository) CreateLockers(ctx context.Context, lockers []Locker) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(lockers, 100).Error
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrNumberTaken
+		}
+		return fmt.Errorf("failed to create lockers: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetLocker(ctx context.Context, festivalID, id uuid.UUID) (*Locker, error) {
+	var locker Locker
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&locker).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get locker: %w", err)
+	}
+	return &locker, nil
+}
+
+func (r *repository) ListLockers(ctx context.Context, festivalID, zoneID uuid.UUID) ([]Locker, error) {
+	var lockers []Locker
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND zone_id = ?", festivalID, zoneID).
+		Order("length(number), number").
+		Find(&lockers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lockers: %w", err)
+	}
+	return lockers, nil
+}
+
+// SetLockerStatus takes a locker out of service or back into service unless a rental
+// occupies it
+func (r *repository) SetLockerStatus(ctx context.Context, locker *Locker, status LockerStatus) error {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&Locker{}).
+		Where("id = ? AND status <> ?", locker.ID, LockerStatusOccupied).
+		Updates(map[string]interface{}{"status": status, "updated_at": now})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update locker: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLockerRented
+	}
+	locker.Status = status
+	locker.UpdatedAt = now
+	return nil
+}
+
+// StartRental occupies the locker of the rental, or the first available locker of its
+// zone when none is set, and records the rental. The locker row is locked so that two
+// rentals never get the same locker.
+func (r *repository) StartRental(ctx context.Context, rental *Rental) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locker Locker
+		query := tx.Raw(`
+			SELECT * FROM lockers
+			WHERE festival_id = ? AND zone_id = ? AND status = ?
+			ORDER BY length(number), number
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED`,
+			rental.FestivalID, rental.ZoneID, LockerStatusAvailable)
+		if rental.LockerID != uuid.Nil {
+			query = tx.Raw(`
+				SELECT * FROM lockers
+				WHERE festival_id = ? AND zone_id = ? AND id = ? AND status = ?
+				FOR UPDATE`,
+				rental.FestivalID, rental.ZoneID, rental.LockerID, LockerStatusAvailable)
+		}
+		if err := query.Scan(&locker).Error; err != nil {
+			return fmt.Errorf("failed to lock locker: %w", err)
+		}
+		if locker.ID == uuid.Nil {
+			if rental.LockerID != uuid.Nil {
+				return ErrLockerUnavailable
+			}
+			return ErrNoLockerAvailable
+		}
+
+		err := tx.Model(&Locker{}).Where("id = ?", locker.ID).
+			Updates(map[string]interface{}{"status": LockerStatusOccupied, "updated_at": time.Now()}).Error
+		if err != nil {
+			return fmt.Errorf("failed to occupy locker: %w", err)
+		}
+
+		rental.LockerID = locker.ID
+		rental.LockerNumber = locker.Number
+		if err := tx.Create(rental).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return errTicketTaken
+			}
+			return fmt.Errorf("failed to create locker rental: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) UpdateRental(ctx context.Context, rental *Rental) error {
+	if err := r.db.WithContext(ctx).Save(rental).Error; err != nil {
+		return fmt.Errorf("failed to update locker rental: %w", err)
+	}
+	return nil
+}
+
+// EndRental records the end of a rental and frees its locker
+func (r *repository) EndRental(ctx context.Context, rental *Rental) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(rental).Error; err != nil {
+			return fmt.Errorf("failed to end locker rental: %w", err)
+		}
+		err := tx.Model(&Locker{}).
+			Where("id = ? AND status = ?", rental.LockerID, LockerStatusOccupied).
+			Updates(map[string]interface{}{"status": LockerStatusAvailable, "updated_at": time.Now()}).Error
+		if err != nil {
+			return fmt.Errorf("failed to free locker: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetRental(ctx context.Context, festivalID, id uuid.UUID) (*Rental, error) {
+	var rental Rental
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&rental).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get locker rental: %w", err)
+	}
+	return &rental, nil
+}
+
+func (r *repository) GetActiveRentalByTicket(ctx context.Context, festivalID uuid.UUID, ticketCode string) (*Rental, error) {
+	var rental Rental
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND ticket_code = ? AND status = ?", festivalID, ticketCode, RentalStatusActive).
+		First(&rental).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get locker rental: %w", err)
+	}
+	return &rental, nil
+}
+
+func (r *repository) ListRentals(ctx context.Context, festivalID uuid.UUID, filter RentalFilter) ([]Rental, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if filter.ZoneID != nil {
+		query = query.Where("zone_id = ?", *filter.ZoneID)
+	}
+	if filter.WalletID != nil {
+		query = query.Where("wallet_id = ?", *filter.WalletID)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.LockerNumber != "" {
+		query = query.Where("locker_number = ?", filter.LockerNumber)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var rentals []Rental
+	if err := query.Order("started_at DESC").Find(&rentals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list locker rentals: %w", err)
+	}
+	return rentals, nil
+}
+
+// GetOccupancy counts the lockers of each zone by status and sums the rentals charged there
+func (r *repository) GetOccupancy(ctx context.Context, festivalID uuid.UUID) ([]ZoneOccupancy, error) {
+	var zones []ZoneOccupancy
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			z.id AS zone_id,
+			z.name,
+			z.kind,
+			z.active,
+			COALESCE(l.lockers, 0) AS lockers,
+			COALESCE(l.available, 0) AS available,
+			COALESCE(l.occupied, 0) AS occupied,
+			COALESCE(l.out_of_service, 0) AS out_of_service,
+			COALESCE(rt.rentals, 0) AS rentals,
+			COALESCE(rt.lost_tickets, 0) AS lost_tickets,
+			COALESCE(rt.revenue, 0) AS revenue
+		FROM locker_zones z
+		LEFT JOIN (
+			SELECT zone_id,
+				COUNT(*) AS lockers,
+				COUNT(*) FILTER (WHERE status = 'AVAILABLE') AS available,
+				COUNT(*) FILTER (WHERE status = 'OCCUPIED') AS occupied,
+				COUNT(*) FILTER (WHERE status = 'OUT_OF_SERVICE') AS out_of_service
+			FROM lockers
+			WHERE festival_id = ?
+			GROUP BY zone_id
+		) l ON l.zone_id = z.id
+		LEFT JOIN (
+			SELECT zone_id,
+				COUNT(*) AS rentals,
+				COUNT(*) FILTER (WHERE status = 'LOST_TICKET') AS lost_tickets,
+				SUM(amount + lost_ticket_fee) AS revenue
+			FROM locker_rentals
+			WHERE festival_id = ? AND status <> 'CANCELLED'
+			GROUP BY zone_id
+		) rt ON rt.zone_id = z.id
+		WHERE z.festival_id = ?
+		ORDER BY z.name`,
+		festivalID, festivalID, festivalID,
+	).Scan(&zones).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locker occupancy: %w", err)
+	}
+	return zones, nil
+}
@@ -0,0 +1,107 @@
+package locker
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateZone(ctx context.Context, zone *Zone) error {
+	args := m.Called(ctx, zone)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateZone(ctx context.Context, zone *Zone) error {
+	args := m.Called(ctx, zone)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetZone(ctx context.Context, festivalID, id uuid.UUID) (*Zone, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Zone), args.Error(1)
+}
+
+func (m *MockRepository) ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Zone), args.Error(1)
+}
+
+func (m *MockRepository) CreateLockers(ctx context.Context, lockers []Locker) error {
+	args := m.Called(ctx, lockers)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetLocker(ctx context.Context, festivalID, id uuid.UUID) (*Locker, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Locker), args.Error(1)
+}
+
+func (m *MockRepository) ListLockers(ctx context.Context, festivalID, zoneID uuid.UUID) ([]Locker, error) {
+	args := m.Called(ctx, festivalID, zoneID)
+	return args.Get(0).([]Locker), args.Error(1)
+}
+
+func (m *MockRepository) SetLockerStatus(ctx context.Context, locker *Locker, status LockerStatus) error {
+	args := m.Called(ctx, locker, status)
+	return args.Error(0)
+}
+
+func (m *MockRepository) StartRental(ctx context.Context, rental *Rental) error {
+	args := m.Called(ctx, rental)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateRental(ctx context.Context, rental *Rental) error {
+	args := m.Called(ctx, rental)
+	return args.Error(0)
+}
+
+func (m *MockRepository) EndRental(ctx context.Context, rental *Rental) error {
+	args := m.Called(ctx, rental)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRental(ctx context.Context, festivalID, id uuid.UUID) (*Rental, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Rental), args.Error(1)
+}
+
+func (m *MockRepository) GetActiveRentalByTicket(ctx context.Context, festivalID uuid.UUID, ticketCode string) (*Rental, error) {
+	args := m.Called(ctx, festivalID, ticketCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Rental), args.Error(1)
+}
+
+func (m *MockRepository) ListRentals(ctx context.Context, festivalID uuid.UUID, filter RentalFilter) ([]Rental, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Rental), args.Error(1)
+}
+
+func (m *MockRepository) GetOccupancy(ctx context.Context, festivalID uuid.UUID) ([]ZoneOccupancy, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]ZoneOccupancy), args.Error(1)
+}
@@ -0,0 +1,492 @@
+package locker
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+)
+
+// ticketAlphabet leaves out the characters mistaken for one another on printed tickets
+const ticketAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// ticketLength is the length of the ticket codes handed to attendees
+const ticketLength = 6
+
+// Wallets charges rentals to the wallets of attendees; satisfied by wallet.Service
+type Wallets interface {
+	GetWallet(ctx context.Context, id uuid.UUID) (*wallet.Wallet, error)
+	Adjust(ctx context.Context, walletID uuid.UUID, req wallet.AdjustRequest, staffID *uuid.UUID) (*wallet.Transaction, error)
+}
+
+// AuditLogger records the lost ticket overrides, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service runs the lockers and gear checks of festivals: their inventory, the rentals
+// charged to wallets and the occupancy shown to the operations team
+type Service struct {
+	repo    Repository
+	wallets Wallets
+	audit   AuditLogger
+	now     func() time.Time
+}
+
+// NewService creates the locker service
+func NewService(repo Repository, wallets Wallets) *Service {
+	return &Service{
+		repo:    repo,
+		wallets: wallets,
+		now:     time.Now,
+	}
+}
+
+// SetAuditLogger records the lost ticket overrides in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// CreateZone adds a bank of lockers or a gear check to the festival
+func (s *Service) CreateZone(ctx context.Context, festivalID uuid.UUID, req CreateZoneRequest) (*Zone, error) {
+	now := s.now()
+	zone := &Zone{
+		ID:            uuid.New(),
+		FestivalID:    festivalID,
+		Name:          strings.TrimSpace(req.Name),
+		Kind:          req.Kind,
+		Location:      strings.TrimSpace(req.Location),
+		Price:         req.Price,
+		LostTicketFee: req.LostTicketFee,
+		Active:        true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.CreateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// UpdateZone changes a zone. Closing it stops new rentals; the active ones can still
+// be returned.
+func (s *Service) UpdateZone(ctx context.Context, festivalID, id uuid.UUID, req UpdateZoneRequest) (*Zone, error) {
+	zone, err := s.GetZone(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		zone.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Location != nil {
+		zone.Location = strings.TrimSpace(*req.Location)
+	}
+	if req.Price != nil {
+		zone.Price = *req.Price
+	}
+	if req.LostTicketFee != nil {
+		zone.LostTicketFee = *req.LostTicketFee
+	}
+	if req.Active != nil {
+		zone.Active = *req.Active
+	}
+	zone.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// GetZone returns a zone of the festival
+func (s *Service) GetZone(ctx context.Context, festivalID, id uuid.UUID) (*Zone, error) {
+	zone, err := s.repo.GetZone(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if zone == nil {
+		return nil, ErrZoneNotFound
+	}
+	return zone, nil
+}
+
+// ListZones lists the zones of the festival
+func (s *Service) ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error) {
+	return s.repo.ListZones(ctx, festivalID)
+}
+
+// CreateLockers adds lockers to a zone
+func (s *Service) CreateLockers(ctx context.Context, festivalID, zoneID uuid.UUID, req CreateLockersRequest) ([]Locker, error) {
+	zone, err := s.GetZone(ctx, festivalID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers, err := LockerNumbers(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	lockers := make([]Locker, len(numbers))
+	for i, number := range numbers {
+		lockers[i] = Locker{
+			ID:         uuid.New(),
+			FestivalID: festivalID,
+			ZoneID:     zone.ID,
+			Number:     number,
+			Status:     LockerStatusAvailable,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+	if err := s.repo.CreateLockers(ctx, lockers); err != nil {
+		return nil, err
+	}
+	return lockers, nil
+}
+
+// ListLockers lists the lockers of a zone in number order
+func (s *Service) ListLockers(ctx context.Context, festivalID, zoneID uuid.UUID) ([]Locker, error) {
+	zone, err := s.GetZone(ctx, festivalID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListLockers(ctx, festivalID, zone.ID)
+}
+
+// UpdateLocker takes a locker out of service, e.g. when it is broken, or back into service
+func (s *Service) UpdateLocker(ctx context.Context, festivalID, id uuid.UUID, req UpdateLockerRequest) (*Locker, error) {
+	if req.Status != LockerStatusAvailable && req.Status != LockerStatusOutOfService {
+		return nil, ErrInvalidStatus
+	}
+
+	locker, err := s.repo.GetLocker(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if locker == nil {
+		return nil, ErrLockerNotFound
+	}
+	if err := s.repo.SetLockerStatus(ctx, locker, req.Status); err != nil {
+		return nil, err
+	}
+	return locker, nil
+}
+
+// StartRental rents a locker to the holder of a wallet and charges the price of the
+// zone to the wallet. When the charge fails the locker is freed again.
+func (s *Service) StartRental(ctx context.Context, festivalID uuid.UUID, req StartRentalRequest, staffID *uuid.UUID) (*Rental, error) {
+	zone, err := s.GetZone(ctx, festivalID, req.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	if !zone.Active {
+		return nil, ErrZoneClosed
+	}
+	if err := s.checkWallet(ctx, festivalID, req.WalletID); err != nil {
+		return nil, err
+	}
+
+	rental := &Rental{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		ZoneID:     zone.ID,
+		WalletID:   req.WalletID,
+		Status:     RentalStatusActive,
+		Amount:     zone.Price,
+		StartedBy:  staffID,
+		StartedAt:  s.now(),
+	}
+	if req.LockerID != nil {
+		rental.LockerID = *req.LockerID
+	}
+
+	// Ticket codes are unique among the active rentals of the festival; draw again on
+	// the rare collision
+	for attempt := 0; ; attempt++ {
+		if rental.TicketCode, err = newTicketCode(); err != nil {
+			return nil, err
+		}
+		err = s.repo.StartRental(ctx, rental)
+		if !errors.Is(err, errTicketTaken) || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if zone.Price > 0 {
+		tx, err := s.charge(ctx, rental, rental.ID, zone.Price, fmt.Sprintf("%s locker %s", zone.Name, rental.LockerNumber), staffID)
+		if err != nil {
+			now := s.now()
+			rental.Status = RentalStatusCancelled
+			rental.Amount = 0
+			rental.EndedAt = &now
+			if endErr := s.repo.EndRental(ctx, rental); endErr != nil {
+				return nil, endErr
+			}
+			return nil, err
+		}
+		rental.TransactionID = &tx.ID
+		if err := s.repo.UpdateRental(ctx, rental); err != nil {
+			return nil, err
+		}
+	}
+	return rental, nil
+}
+
+// ReturnRental ends the rental a ticket was issued for when the attendee collects
+// their items, and frees the locker
+func (s *Service) ReturnRental(ctx context.Context, festivalID uuid.UUID, req ReturnRequest, staffID *uuid.UUID) (*Rental, error) {
+	rental, err := s.repo.GetActiveRentalByTicket(ctx, festivalID, NormalizeTicketCode(req.TicketCode))
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil {
+		return nil, ErrInvalidTicket
+	}
+
+	now := s.now()
+	rental.Status = RentalStatusReturned
+	rental.EndedBy = staffID
+	rental.EndedAt = &now
+	if err := s.repo.EndRental(ctx, rental); err != nil {
+		return nil, err
+	}
+	return rental, nil
+}
+
+// ReleaseLostTicket ends a rental whose ticket was lost, once staff checked that the
+// attendee owns the items. The lost ticket fee of the zone is charged to the wallet of
+// the rental, and the override is recorded in the audit log.
+func (s *Service) ReleaseLostTicket(ctx context.Context, festivalID, rentalID uuid.UUID, req LostTicketRequest, staffID *uuid.UUID) (*Rental, error) {
+	rental, err := s.GetRental(ctx, festivalID, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rental.Status != RentalStatusActive {
+		return nil, ErrRentalEnded
+	}
+	zone, err := s.GetZone(ctx, festivalID, rental.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	if zone.LostTicketFee > 0 {
+		// The fee transaction ID is derived from the rental, so that a retried
+		// override does not charge the fee twice
+		feeID := uuid.NewSHA1(rental.ID, []byte("lost-ticket"))
+		tx, err := s.charge(ctx, rental, feeID, zone.LostTicketFee, fmt.Sprintf("%s locker %s lost ticket", zone.Name, rental.LockerNumber), staffID)
+		switch {
+		case err == nil:
+			rental.FeeTransactionID = &tx.ID
+		case errors.Is(err, wallet.ErrAdjustmentApplied):
+			rental.FeeTransactionID = &feeID
+		default:
+			return nil, err
+		}
+		rental.LostTicketFee = zone.LostTicketFee
+	}
+
+	now := s.now()
+	rental.Status = RentalStatusLostTicket
+	rental.OverrideReason = strings.TrimSpace(req.Reason)
+	rental.EndedBy = staffID
+	rental.EndedAt = &now
+	if err := s.repo.EndRental(ctx, rental); err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     staffID,
+			Action:     audit.ActionUpdate,
+			Resource:   "locker_rental",
+			ResourceID: rental.ID.String(),
+			FestivalID: &festivalID,
+			Metadata: map[string]interface{}{
+				"override":      "lost_ticket",
+				"zoneId":        rental.ZoneID.String(),
+				"lockerNumber":  rental.LockerNumber,
+				"walletId":      rental.WalletID.String(),
+				"lostTicketFee": rental.LostTicketFee,
+				"reason":        rental.OverrideReason,
+			},
+		})
+	}
+	return rental, nil
+}
+
+// GetRental returns a rental of the festival
+func (s *Service) GetRental(ctx context.Context, festivalID, id uuid.UUID) (*Rental, error) {
+	rental, err := s.repo.GetRental(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil {
+		return nil, ErrRentalNotFound
+	}
+	return rental, nil
+}
+
+// ListRentals lists the rentals of the festival, latest first
+func (s *Service) ListRentals(ctx context.Context, festivalID uuid.UUID, filter RentalFilter) ([]Rental, error) {
+	filter.LockerNumber = strings.TrimSpace(filter.LockerNumber)
+	return s.repo.ListRentals(ctx, festivalID, filter)
+}
+
+// GetOccupancy returns the use of the lockers of each zone and of the festival
+func (s *Service) GetOccupancy(ctx context.Context, festivalID uuid.UUID) (*Occupancy, error) {
+	zones, err := s.repo.GetOccupancy(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeOccupancy(festivalID, zones, s.now()), nil
+}
+
+// ComputeOccupancy sums the zones into the festival totals and computes the occupancy
+// of each
+func ComputeOccupancy(festivalID uuid.UUID, zones []ZoneOccupancy, now time.Time) *Occupancy {
+	occupancy := &Occupancy{
+		FestivalID:  festivalID,
+		Zones:       make([]ZoneOccupancy, len(zones)),
+		GeneratedAt: now,
+	}
+	occupancy.Totals.Name = "Total"
+	occupancy.Totals.Active = true
+	for i, zone := range zones {
+		zone.OccupancyPercent = occupancyPercent(zone)
+		occupancy.Zones[i] = zone
+		occupancy.Totals.Lockers += zone.Lockers
+		occupancy.Totals.Available += zone.Available
+		occupancy.Totals.Occupied += zone.Occupied
+		occupancy.Totals.OutOfService += zone.OutOfService
+		occupancy.Totals.Rentals += zone.Rentals
+		occupancy.Totals.LostTickets += zone.LostTickets
+		occupancy.Totals.Revenue += zone.Revenue
+	}
+	occupancy.Totals.OccupancyPercent = occupancyPercent(occupancy.Totals)
+	return occupancy
+}
+
+// occupancyPercent is the share of the lockers in service that are occupied, to one decimal
+func occupancyPercent(zone ZoneOccupancy) float64 {
+	inService := zone.Lockers - zone.OutOfService
+	if inService <= 0 {
+		return 0
+	}
+	return math.Round(float64(zone.Occupied)/float64(inService)*1000) / 10
+}
+
+// LockerNumbers returns the numbers of the lockers a request adds: its numbers, or the
+// range of Count numbers from From with Prefix, zero padded to Padding digits
+func LockerNumbers(req CreateLockersRequest) ([]string, error) {
+	var numbers []string
+	seen := make(map[string]bool)
+	add := func(number string) error {
+		number = strings.TrimSpace(number)
+		if number == "" {
+			return fmt.Errorf("%w: numbers must not be blank", ErrInvalidLockers)
+		}
+		if seen[number] {
+			return fmt.Errorf("%w: %s", ErrNumberTaken, number)
+		}
+		seen[number] = true
+		numbers = append(numbers, number)
+		return nil
+	}
+
+	for _, number := range req.Numbers {
+		if err := add(number); err != nil {
+			return nil, err
+		}
+	}
+	padding := req.Padding
+	if padding == 0 {
+		padding = 1
+	}
+	for i := 0; i < req.Count; i++ {
+		seq := strconv.Itoa(req.From + i)
+		if len(seq) < padding {
+			seq = strings.Repeat("0", padding-len(seq)) + seq
+		}
+		if err := add(req.Prefix + seq); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(numbers) == 0 || len(numbers) > MaxLockersPerRequest {
+		return nil, fmt.Errorf("%w: add between 1 and %d lockers", ErrInvalidLockers, MaxLockersPerRequest)
+	}
+	return numbers, nil
+}
+
+// NormalizeTicketCode formats a ticket code as printed, ignoring case, spaces and dashes
+func NormalizeTicketCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+// checkWallet checks that a wallet of the festival can be charged
+func (s *Service) checkWallet(ctx context.Context, festivalID, walletID uuid.UUID) error {
+	w, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return ErrWalletNotFound
+		}
+		return err
+	}
+	if w.FestivalID != festivalID {
+		return ErrWalletNotFound
+	}
+	if w.Status != wallet.WalletStatusActive {
+		return ErrWalletNotActive
+	}
+	return nil
+}
+
+// charge debits amount from the wallet of a rental under transactionID
+func (s *Service) charge(ctx context.Context, rental *Rental, transactionID uuid.UUID, amount int64, reason string, staffID *uuid.UUID) (*wallet.Transaction, error) {
+	tx, err := s.wallets.Adjust(ctx, rental.WalletID, wallet.AdjustRequest{
+		TransactionID: transactionID,
+		Amount:        -amount,
+		Reason:        reason,
+		Reference:     "locker_rental:" + rental.ID.String(),
+	}, staffID)
+	switch {
+	case err == nil:
+		return tx, nil
+	case errors.Is(err, wallet.ErrAdjustmentInsufficient):
+		return nil, ErrInsufficientBalance
+	case errors.Is(err, wallet.ErrAdjustmentNotActive):
+		return nil, ErrWalletNotActive
+	case errors.Is(err, wallet.ErrAdjustmentApplied):
+		return nil, err
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrChargeFailed, err)
+	}
+}
+
+func newTicketCode() (string, error) {
+	raw := make([]byte, ticketLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate ticket code: %w", err)
+	}
+	code := make([]byte, ticketLength)
+	for i, b := range raw {
+		code[i] = ticketAlphabet[int(b)%len(ticketAlphabet)]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,245 @@
+package locker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWallets debits wallets idempotently by transaction ID like wallet.Service.Adjust
+type fakeWallets struct {
+	wallets map[uuid.UUID]*wallet.Wallet
+	applied map[uuid.UUID]bool
+}
+
+func (w *fakeWallets) GetWallet(ctx context.Context, id uuid.UUID) (*wallet.Wallet, error) {
+	if found, ok := w.wallets[id]; ok {
+		return found, nil
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (w *fakeWallets) Adjust(ctx context.Context, walletID uuid.UUID, req wallet.AdjustRequest, staffID *uuid.UUID) (*wallet.Transaction, error) {
+	if w.applied[req.TransactionID] {
+		return nil, wallet.ErrAdjustmentApplied
+	}
+	found := w.wallets[walletID]
+	if found.Balance+req.Amount < 0 {
+		return nil, wallet.ErrAdjustmentInsufficient
+	}
+	w.applied[req.TransactionID] = true
+	found.Balance += req.Amount
+	return &wallet.Transaction{ID: req.TransactionID, WalletID: walletID, Amount: req.Amount}, nil
+}
+
+type lockerFixture struct {
+	service    *Service
+	mockRepo   *MockRepository
+	wallets    *fakeWallets
+	festivalID uuid.UUID
+	zone       *Zone
+	lockers    []Locker
+	walletID   uuid.UUID
+}
+
+// newLockerFixture sets up a zone of two lockers at 5.00 with a 10.00 lost ticket fee
+// and a wallet holding balance. The zone is served back as stored, so updates to it
+// are kept.
+func newLockerFixture(t *testing.T, balance int64) *lockerFixture {
+	f := &lockerFixture{
+		mockRepo:   NewMockRepository(),
+		wallets:    &fakeWallets{wallets: map[uuid.UUID]*wallet.Wallet{}, applied: map[uuid.UUID]bool{}},
+		festivalID: uuid.New(),
+		walletID:   uuid.New(),
+	}
+	f.wallets.wallets[f.walletID] = &wallet.Wallet{ID: f.walletID, FestivalID: f.festivalID, Balance: balance, Status: wallet.WalletStatusActive}
+	f.service = NewService(f.mockRepo, f.wallets)
+	f.service.now = func() time.Time { return time.Date(2026, 7, 18, 14, 0, 0, 0, time.UTC) }
+
+	f.zone = &Zone{ID: uuid.New(), FestivalID: f.festivalID, Name: "Main entrance", Kind: ZoneKindLocker, Price: 500, LostTicketFee: 1000, Active: true}
+	for _, number := range []string{"A-001", "A-002"} {
+		f.lockers = append(f.lockers, Locker{ID: uuid.New(), FestivalID: f.festivalID, ZoneID: f.zone.ID, Number: number, Status: LockerStatusAvailable})
+	}
+	f.mockRepo.On("GetZone", mock.Anything, f.festivalID, f.zone.ID).Return(f.zone, nil)
+	return f
+}
+
+// expectRental lets the next rental of the zone occupy locker
+func (f *lockerFixture) expectRental(locker *Locker) {
+	f.mockRepo.On("StartRental", mock.Anything, mock.MatchedBy(func(r *Rental) bool { return r.ZoneID == f.zone.ID })).
+		Run(func(args mock.Arguments) {
+			rental := args.Get(1).(*Rental)
+			rental.LockerID = locker.ID
+			rental.LockerNumber = locker.Number
+		}).
+		Return(nil).Once()
+}
+
+// start starts a rental of the first locker charged to the wallet
+func (f *lockerFixture) start(t *testing.T, staffID *uuid.UUID) *Rental {
+	f.expectRental(&f.lockers[0])
+	f.mockRepo.On("UpdateRental", mock.Anything, mock.AnythingOfType("*locker.Rental")).Return(nil).Once()
+	rental, err := f.service.StartRental(context.Background(), f.festivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, staffID)
+	require.NoError(t, err)
+	return rental
+}
+
+func TestLockerNumbers(t *testing.T) {
+	numbers, err := LockerNumbers(CreateLockersRequest{Prefix: "A-", From: 9, Count: 3, Padding: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A-009", "A-010", "A-011"}, numbers)
+
+	numbers, err = LockerNumbers(CreateLockersRequest{Numbers: []string{" 12 ", "B1"}, From: 1, Count: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"12", "B1", "1", "2"}, numbers)
+
+	_, err = LockerNumbers(CreateLockersRequest{Numbers: []string{"1"}, From: 1, Count: 1})
+	assert.ErrorIs(t, err, ErrNumberTaken)
+	_, err = LockerNumbers(CreateLockersRequest{Numbers: []string{" "}})
+	assert.ErrorIs(t, err, ErrInvalidLockers)
+	_, err = LockerNumbers(CreateLockersRequest{})
+	assert.ErrorIs(t, err, ErrInvalidLockers)
+	_, err = LockerNumbers(CreateLockersRequest{Numbers: []string{"0"}, From: 1, Count: MaxLockersPerRequest})
+	assert.ErrorIs(t, err, ErrInvalidLockers)
+}
+
+func TestService_StartRental_ChargesWallet(t *testing.T) {
+	f := newLockerFixture(t, 2000)
+	ctx := context.Background()
+
+	rental := f.start(t, nil)
+	assert.Equal(t, "A-001", rental.LockerNumber)
+	assert.Equal(t, RentalStatusActive, rental.Status)
+	assert.Equal(t, int64(500), rental.Amount)
+	assert.Len(t, rental.TicketCode, ticketLength)
+	require.NotNil(t, rental.TransactionID)
+	assert.Equal(t, rental.ID, *rental.TransactionID, "charged under the rental ID")
+	assert.Equal(t, int64(1500), f.wallets.wallets[f.walletID].Balance)
+
+	f.expectRental(&f.lockers[1])
+	f.mockRepo.On("UpdateRental", mock.Anything, mock.AnythingOfType("*locker.Rental")).Return(nil).Once()
+	_, err := f.service.StartRental(ctx, f.festivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, nil)
+	require.NoError(t, err)
+
+	f.mockRepo.On("StartRental", mock.Anything, mock.AnythingOfType("*locker.Rental")).Return(ErrNoLockerAvailable).Once()
+	_, err = f.service.StartRental(ctx, f.festivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, nil)
+	assert.ErrorIs(t, err, ErrNoLockerAvailable)
+	assert.Equal(t, int64(1000), f.wallets.wallets[f.walletID].Balance)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_StartRental_CancelledWhenChargeFails(t *testing.T) {
+	f := newLockerFixture(t, 300)
+	ctx := context.Background()
+
+	// The locker is freed again with the cancelled rental
+	f.expectRental(&f.lockers[0])
+	f.mockRepo.On("EndRental", mock.Anything, mock.MatchedBy(func(r *Rental) bool {
+		return r.Status == RentalStatusCancelled && r.Amount == 0 && r.EndedAt != nil && r.LockerID == f.lockers[0].ID
+	})).Return(nil).Once()
+	_, err := f.service.StartRental(ctx, f.festivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, nil)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.Equal(t, int64(300), f.wallets.wallets[f.walletID].Balance)
+
+	f.wallets.wallets[f.walletID].Status = wallet.WalletStatusFrozen
+	_, err = f.service.StartRental(ctx, f.festivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, nil)
+	assert.ErrorIs(t, err, ErrWalletNotActive)
+
+	otherFestivalID := uuid.New()
+	f.mockRepo.On("GetZone", mock.Anything, otherFestivalID, f.zone.ID).Return(nil, nil).Once()
+	_, err = f.service.StartRental(ctx, otherFestivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, nil)
+	assert.ErrorIs(t, err, ErrZoneNotFound)
+
+	closed := false
+	f.mockRepo.On("UpdateZone", mock.Anything, f.zone).Return(nil).Once()
+	_, err = f.service.UpdateZone(ctx, f.festivalID, f.zone.ID, UpdateZoneRequest{Active: &closed})
+	require.NoError(t, err)
+	_, err = f.service.StartRental(ctx, f.festivalID, StartRentalRequest{ZoneID: f.zone.ID, WalletID: f.walletID}, nil)
+	assert.ErrorIs(t, err, ErrZoneClosed)
+
+	f.mockRepo.AssertExpectations(t)
+	f.mockRepo.AssertNotCalled(t, "UpdateRental", mock.Anything, mock.Anything)
+}
+
+func TestService_ReturnRental(t *testing.T) {
+	f := newLockerFixture(t, 2000)
+	ctx := context.Background()
+	staffID := uuid.New()
+	rental := f.start(t, &staffID)
+
+	f.mockRepo.On("GetLocker", mock.Anything, f.festivalID, rental.LockerID).Return(&f.lockers[0], nil).Once()
+	f.mockRepo.On("SetLockerStatus", mock.Anything, &f.lockers[0], LockerStatusOutOfService).Return(ErrLockerRented).Once()
+	_, err := f.service.UpdateLocker(ctx, f.festivalID, rental.LockerID, UpdateLockerRequest{Status: LockerStatusOutOfService})
+	assert.ErrorIs(t, err, ErrLockerRented)
+
+	// Tickets are looked up without the separator and surrounding spaces
+	f.mockRepo.On("GetActiveRentalByTicket", mock.Anything, f.festivalID, rental.TicketCode).Return(rental, nil).Once()
+	f.mockRepo.On("EndRental", mock.Anything, rental).Return(nil).Once()
+	ticket := " " + rental.TicketCode[:3] + "-" + rental.TicketCode[3:] + " "
+	returned, err := f.service.ReturnRental(ctx, f.festivalID, ReturnRequest{TicketCode: ticket}, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, RentalStatusReturned, returned.Status)
+	assert.Equal(t, &staffID, returned.EndedBy)
+	assert.NotNil(t, returned.EndedAt)
+
+	f.mockRepo.On("GetActiveRentalByTicket", mock.Anything, f.festivalID, rental.TicketCode).Return(nil, nil).Once()
+	_, err = f.service.ReturnRental(ctx, f.festivalID, ReturnRequest{TicketCode: rental.TicketCode}, &staffID)
+	assert.ErrorIs(t, err, ErrInvalidTicket, "a ticket opens its locker once")
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_ReleaseLostTicket(t *testing.T) {
+	f := newLockerFixture(t, 2000)
+	ctx := context.Background()
+	staffID := uuid.New()
+	rental := f.start(t, &staffID)
+
+	// The rental is served back as stored, so a released rental cannot be released again
+	f.mockRepo.On("GetRental", mock.Anything, f.festivalID, rental.ID).Return(rental, nil)
+	f.mockRepo.On("EndRental", mock.Anything, rental).Return(nil).Once()
+	released, err := f.service.ReleaseLostTicket(ctx, f.festivalID, rental.ID, LostTicketRequest{Reason: " Wristband matches the rental wallet "}, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, RentalStatusLostTicket, released.Status)
+	assert.Equal(t, int64(1000), released.LostTicketFee)
+	assert.Equal(t, "Wristband matches the rental wallet", released.OverrideReason)
+	require.NotNil(t, released.FeeTransactionID)
+	assert.Equal(t, int64(500), f.wallets.wallets[f.walletID].Balance, "rental price and lost ticket fee charged")
+
+	_, err = f.service.ReleaseLostTicket(ctx, f.festivalID, rental.ID, LostTicketRequest{Reason: "again"}, &staffID)
+	assert.ErrorIs(t, err, ErrRentalEnded)
+
+	// A retried override after the fee was charged does not charge it twice
+	retried := f.start(t, &staffID)
+	feeID := uuid.NewSHA1(retried.ID, []byte("lost-ticket"))
+	f.wallets.applied[feeID] = true
+	f.mockRepo.On("GetRental", mock.Anything, f.festivalID, retried.ID).Return(retried, nil).Once()
+	f.mockRepo.On("EndRental", mock.Anything, retried).Return(nil).Once()
+	released, err = f.service.ReleaseLostTicket(ctx, f.festivalID, retried.ID, LostTicketRequest{Reason: "ID checked"}, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, RentalStatusLostTicket, released.Status)
+	assert.Equal(t, &feeID, released.FeeTransactionID)
+	assert.Equal(t, int64(0), f.wallets.wallets[f.walletID].Balance)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestComputeOccupancy(t *testing.T) {
+	festivalID := uuid.New()
+	occupancy := ComputeOccupancy(festivalID, []ZoneOccupancy{
+		{Name: "Main entrance", Lockers: 10, Available: 6, Occupied: 3, OutOfService: 1, Rentals: 5, LostTickets: 1, Revenue: 3500},
+		{Name: "Camping", Lockers: 0},
+	}, time.Now())
+
+	assert.Equal(t, 33.3, occupancy.Zones[0].OccupancyPercent, "occupied over the lockers in service")
+	assert.Zero(t, occupancy.Zones[1].OccupancyPercent)
+	assert.Equal(t, int64(10), occupancy.Totals.Lockers)
+	assert.Equal(t, int64(3), occupancy.Totals.Occupied)
+	assert.Equal(t, int64(3500), occupancy.Totals.Revenue)
+	assert.Equal(t, 33.3, occupancy.Totals.OccupancyPercent)
+}
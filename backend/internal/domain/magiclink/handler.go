@@ -0,0 +1,102 @@
+package magiclink
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the public sign-in routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("", h.RequestLink)
+	r.POST("/verify", h.Verify)
+}
+
+// RequestLink emails a sign-in link
+// @Summary Request magic link
+// @Description Email a sign-in link to an attendee, valid 15 minutes and usable once. The response is the same whether or not a link was sent, so it does not tell which emails have an account; staff, organizers and administrators get no link.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RequestLinkRequest true "Email"
+// @Success 202 {object} response.Response "Link sent if the email can sign in with one"
+// @Failure 400 {object} response.ErrorResponse "Invalid email"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Router /auth/magic-link [post]
+func (h *Handler) RequestLink(c *gin.Context) {
+	var req RequestLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	err := h.service.RequestLink(c.Request.Context(), req, RequestInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Locale:    response.Locale(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, gin.H{"message": "If the email can sign in with a link, one is on its way"})
+}
+
+// Verify signs in with a magic link
+// @Summary Verify magic link
+// @Description Sign in with the token of a magic link. The attendee is created on their first sign-in and gets a session token valid 24 hours, accepted next to Auth0 tokens.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyLinkRequest true "Token of the link"
+// @Success 200 {object} response.Response{data=Session} "Signed in"
+// @Failure 400 {object} response.ErrorResponse "Link invalid, expired or already used"
+// @Failure 403 {object} response.ErrorResponse "Not an attendee, or banned user"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Router /auth/magic-link/verify [post]
+func (h *Handler) Verify(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var req VerifyLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	session, err := h.service.Verify(c.Request.Context(), req.Token, RequestInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, session)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidEmail):
+		response.BadRequest(c, "INVALID_EMAIL", err.Error(), nil)
+	case errors.Is(err, ErrInvalidLink):
+		response.BadRequest(c, "INVALID_LINK", err.Error(), nil)
+	case errors.Is(err, ErrNotAttendee), errors.Is(err, user.ErrUserBanned):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrNoLinkURL):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
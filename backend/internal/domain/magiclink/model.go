@@ -0,0 +1,67 @@
+package magiclink
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+)
+
+var (
+	ErrInvalidEmail = errors.New("invalid email address")
+	ErrInvalidLink  = errors.New("sign-in link is invalid, expired or already used")
+	ErrNotAttendee  = errors.New("magic links only sign in attendees")
+	ErrNoLinkURL    = errors.New("magic link sign-in is not configured")
+)
+
+const (
+	// LinkTTL is how long a sign-in link can be used
+	LinkTTL = 15 * time.Minute
+	// SessionTTL is the lifetime of the session tokens of attendees signed in with a link
+	SessionTTL = 24 * time.Hour
+	// MaxLinksPerEmail limits the links sent to an email within LinkRequestWindow, so the
+	// endpoint cannot be used to flood a mailbox
+	MaxLinksPerEmail  = 5
+	LinkRequestWindow = time.Hour
+)
+
+// Link is what a sign-in link stands for, stored until it is used or expires
+type Link struct {
+	Email     string    `json:"email"`
+	Locale    string    `json:"locale"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LinkEmail is the email carrying a sign-in link
+type LinkEmail struct {
+	To        string
+	URL       string
+	ExpiresIn time.Duration
+	Locale    string
+}
+
+// RequestLinkRequest asks for a sign-in link
+type RequestLinkRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// VerifyLinkRequest signs in with the token of a link
+type VerifyLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Session is the result of a sign-in with a link
+type Session struct {
+	Token     string    `json:"token"` // Bearer token accepted next to Auth0 tokens
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      user.User `json:"user"`
+}
+
+// RequestInfo identifies the client of a request in the audit log
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	Locale    string
+}
@@ -0,0 +1,79 @@
+package magiclink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys of the links, by nonce, and of the link counters, by email
+const (
+	linkKeyPrefix    = "magic_link:"
+	counterKeyPrefix = "magic_link:count:"
+)
+
+type Repository interface {
+	// SaveLink stores a link until it expires
+	SaveLink(ctx context.Context, nonce string, link *Link) error
+	// TakeLink returns a link and deletes it, so it is used once. It returns nil when the
+	// link does not exist or expired.
+	TakeLink(ctx context.Context, nonce string) (*Link, error)
+	// CountLink counts a link sent to an email and returns the links sent to it within
+	// the window
+	CountLink(ctx context.Context, email string, window time.Duration) (int64, error)
+}
+
+type repository struct {
+	redisClient *redis.Client
+}
+
+// NewRepository creates a link store in Redis, where links expire with their TTL
+func NewRepository(redisClient *redis.Client) Repository {
+	return &repository{redisClient: redisClient}
+}
+
+func (r *repository) SaveLink(ctx context.Context, nonce string, link *Link) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("failed to marshal magic link: %w", err)
+	}
+	if err := r.redisClient.Set(ctx, linkKeyPrefix+nonce, data, time.Until(link.ExpiresAt)).Err(); err != nil {
+		return fmt.Errorf("failed to save magic link: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) TakeLink(ctx context.Context, nonce string) (*Link, error) {
+	data, err := r.redisClient.GetDel(ctx, linkKeyPrefix+nonce).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to take magic link: %w", err)
+	}
+
+	var link Link
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal magic link: %w", err)
+	}
+	return &link, nil
+}
+
+func (r *repository) CountLink(ctx context.Context, email string, window time.Duration) (int64, error) {
+	key := counterKeyPrefix + email
+	count, err := r.redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count magic links: %w", err)
+	}
+	// The window starts with the first link, the next ones do not extend it
+	if count == 1 {
+		if err := r.redisClient.Expire(ctx, key, window).Err(); err != nil {
+			return 0, fmt.Errorf("failed to count magic links: %w", err)
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,38 @@
+package magiclink
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) SaveLink(ctx context.Context, nonce string, link *Link) error {
+	args := m.Called(ctx, nonce, link)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TakeLink(ctx context.Context, nonce string) (*Link, error) {
+	args := m.Called(ctx, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Link), args.Error(1)
+}
+
+func (m *MockRepository) CountLink(ctx context.Context, email string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, email, window)
+	return args.Get(0).(int64), args.Error(1)
+}
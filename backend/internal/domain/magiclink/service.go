@@ -0,0 +1,276 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	pkgerrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/rs/zerolog/log"
+)
+
+// linkKeyLabel separates the link signing key from the other values signed with the
+// keyring secrets
+const linkKeyLabel = "magic-link"
+
+// UserProvisioner finds the users requesting a link and provisions the attendees signing
+// in, satisfied by *user.Service
+type UserProvisioner interface {
+	GetByEmail(ctx context.Context, email string) (*user.User, error)
+	ProvisionFromEmail(ctx context.Context, email string) (*user.User, bool, error)
+}
+
+// Mailer sends the sign-in links through the email worker, satisfied by
+// *jobs.EmailQueue
+type Mailer interface {
+	SendMagicLink(ctx context.Context, email LinkEmail) error
+}
+
+// AuditLogger records the sign-ins and the users they create, satisfied by
+// *audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Config configures the sign-in links
+type Config struct {
+	LinkURL string // Page of the frontend the links open, which posts their token to the verify endpoint
+}
+
+// Service signs attendees in with links sent to their email. A link is signed with the
+// current keyring secret, stored in Redis for LinkTTL and used once; it proves the
+// attendee owns the email, so a verified link provisions them and returns a session
+// token accepted by the Auth middleware next to Auth0 tokens.
+type Service struct {
+	repo    Repository
+	users   UserProvisioner
+	mailer  Mailer
+	keyring *security.Keyring
+	config  Config
+	audit   AuditLogger
+	now     func() time.Time
+}
+
+// NewService creates a magic link service
+func NewService(repo Repository, users UserProvisioner, mailer Mailer, keyring *security.Keyring, config Config) *Service {
+	return &Service{
+		repo:    repo,
+		users:   users,
+		mailer:  mailer,
+		keyring: keyring,
+		config:  config,
+		now:     time.Now,
+	}
+}
+
+// SetAuditLogger records sign-ins in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// RequestLink emails a sign-in link. Whether a link was sent is never returned, so the
+// endpoint does not tell which emails have an account: no link is sent to staff,
+// organizers, administrators and banned users, who sign in otherwise, nor past
+// MaxLinksPerEmail links within LinkRequestWindow.
+func (s *Service) RequestLink(ctx context.Context, req RequestLinkRequest, info RequestInfo) error {
+	if s.config.LinkURL == "" {
+		return ErrNoLinkURL
+	}
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountLink(ctx, email, LinkRequestWindow)
+	if err != nil {
+		return err
+	}
+	if count > MaxLinksPerEmail {
+		log.Warn().Str("email", email).Str("ip", info.IP).Msg("Magic link limit reached")
+		return nil
+	}
+
+	existing, err := s.users.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pkgerrors.ErrNotFound) {
+		return err
+	}
+	if existing != nil && (existing.Role != user.UserRoleUser || existing.Status == user.UserStatusBanned) {
+		log.Info().Str("user_id", existing.ID.String()).Msg("Magic link not sent to a user who is not an active attendee")
+		return nil
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	link := &Link{
+		Email:     email,
+		Locale:    info.Locale,
+		IP:        info.IP,
+		CreatedAt: now,
+		ExpiresAt: now.Add(LinkTTL),
+	}
+	if err := s.repo.SaveLink(ctx, nonce, link); err != nil {
+		return err
+	}
+
+	token := nonce + "." + signNonce(s.keyring.Current(), nonce)
+	return s.mailer.SendMagicLink(ctx, LinkEmail{
+		To:        email,
+		URL:       s.config.LinkURL + "?token=" + url.QueryEscape(token),
+		ExpiresIn: LinkTTL,
+		Locale:    info.Locale,
+	})
+}
+
+// Verify signs in with the token of a link, which can only be used once. The attendee is
+// created on their first sign-in.
+func (s *Service) Verify(ctx context.Context, token string, info RequestInfo) (*Session, error) {
+	link, err := s.takeLink(ctx, token)
+	if err != nil {
+		email := ""
+		if link != nil {
+			email = link.Email
+		}
+		s.logSignIn(ctx, audit.ActionLoginFailed, nil, info, map[string]interface{}{
+			"email": email,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	session, created, err := s.signIn(ctx, link)
+	if err != nil {
+		s.logSignIn(ctx, audit.ActionLoginFailed, nil, info, map[string]interface{}{
+			"email": link.Email,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	if created {
+		s.logSignIn(ctx, audit.ActionUserCreate, &session.User, info, map[string]interface{}{
+			"role": session.User.Role,
+		})
+	}
+	s.logSignIn(ctx, audit.ActionLogin, &session.User, info, map[string]interface{}{
+		"role": session.User.Role,
+	})
+	return session, nil
+}
+
+// takeLink checks the signature of a token and takes the link it stands for
+func (s *Service) takeLink(ctx context.Context, token string) (*Link, error) {
+	nonce, signature, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" {
+		return nil, ErrInvalidLink
+	}
+	if !s.keyring.Verify(signature, func(secret []byte) string { return signNonce(secret, nonce) }) {
+		return nil, ErrInvalidLink
+	}
+
+	link, err := s.repo.TakeLink(ctx, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrInvalidLink
+	}
+	if !s.now().Before(link.ExpiresAt) {
+		return link, ErrInvalidLink
+	}
+	return link, nil
+}
+
+// signIn provisions the attendee of a link and issues their session token
+func (s *Service) signIn(ctx context.Context, link *Link) (*Session, bool, error) {
+	u, created, err := s.users.ProvisionFromEmail(ctx, link.Email)
+	if err != nil {
+		return nil, false, err
+	}
+	// The role may have changed since the link was sent
+	if u.Role != user.UserRoleUser {
+		return nil, false, ErrNotAttendee
+	}
+
+	now := s.now()
+	expiresAt := now.Add(SessionTTL)
+	signed, err := security.SignSessionToken(s.keyring, security.SessionClaims{
+		Email:         u.Email,
+		EmailVerified: true,
+		Name:          u.Name,
+		Roles:         []string{string(u.Role)},
+		Method:        security.SessionMethodMagicLink,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   u.Auth0ID,
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &Session{Token: signed, ExpiresAt: expiresAt, User: *u}, created, nil
+}
+
+func (s *Service) logSignIn(ctx context.Context, action audit.AuditAction, u *user.User, info RequestInfo, metadata map[string]interface{}) {
+	if s.audit == nil {
+		return
+	}
+	req := audit.CreateAuditLogRequest{
+		Action:    action,
+		Resource:  "user",
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Metadata:  metadata,
+	}
+	if u != nil {
+		req.UserID = &u.ID
+		req.ResourceID = u.ID.String()
+	}
+	metadata["method"] = security.SessionMethodMagicLink
+	s.audit.LogActionAsync(ctx, req)
+}
+
+// normalizeEmail checks an email address and lowercases it
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// signNonce signs the nonce of a link with a key derived from a keyring secret
+func signNonce(secret []byte, nonce string) string {
+	key := hmac.New(sha256.New, secret)
+	key.Write([]byte(linkKeyLabel))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
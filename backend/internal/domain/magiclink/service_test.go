@@ -0,0 +1,194 @@
+package magiclink
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	pkgerrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsers struct {
+	users map[string]*user.User
+}
+
+func (u *fakeUsers) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	if found, ok := u.users[email]; ok {
+		return found, nil
+	}
+	return nil, pkgerrors.ErrNotFound
+}
+
+func (u *fakeUsers) ProvisionFromEmail(ctx context.Context, email string) (*user.User, bool, error) {
+	if found, ok := u.users[email]; ok {
+		if found.Status == user.UserStatusBanned {
+			return nil, false, user.ErrUserBanned
+		}
+		return found, false, nil
+	}
+	id := uuid.New()
+	created := &user.User{ID: id, Email: email, Role: user.UserRoleUser, Auth0ID: user.EmailSubjectPrefix + id.String()}
+	u.users[email] = created
+	return created, true, nil
+}
+
+type fakeMailer struct {
+	emails []LinkEmail
+}
+
+func (m *fakeMailer) SendMagicLink(ctx context.Context, email LinkEmail) error {
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+type fakeAudit struct {
+	actions []audit.AuditAction
+}
+
+func (a *fakeAudit) LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest) {
+	a.actions = append(a.actions, req.Action)
+}
+
+func newTestService(repo Repository) (*Service, *fakeUsers, *fakeMailer, *fakeAudit) {
+	users := &fakeUsers{users: make(map[string]*user.User)}
+	mailer := &fakeMailer{}
+	auditLog := &fakeAudit{}
+	service := NewService(repo, users, mailer, security.NewKeyring("secret", nil, time.Hour), Config{
+		LinkURL: "https://app.festivals.test/login/link",
+	})
+	service.SetAuditLogger(auditLog)
+	return service, users, mailer, auditLog
+}
+
+// expectRequests counts the next requests of links for an email
+func expectRequests(mockRepo *MockRepository, email string, requests int) {
+	for i := 1; i <= requests; i++ {
+		mockRepo.On("CountLink", mock.Anything, email, LinkRequestWindow).Return(int64(i), nil).Once()
+	}
+}
+
+// expectLinks stores the links sent, each taken once
+func expectLinks(mockRepo *MockRepository) {
+	mockRepo.On("SaveLink", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*magiclink.Link")).
+		Run(func(args mock.Arguments) {
+			link := *args.Get(2).(*Link)
+			mockRepo.On("TakeLink", mock.Anything, args.String(1)).Return(&link, nil).Once()
+		}).
+		Return(nil)
+}
+
+// linkToken reads the token of the link of an email
+func linkToken(t *testing.T, email LinkEmail) string {
+	u, err := url.Parse(email.URL)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func TestService_SignInWithLink(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, mailer, auditLog := newTestService(mockRepo)
+	ctx := context.Background()
+	expectRequests(mockRepo, "sam@example.test", 1)
+	expectLinks(mockRepo)
+
+	require.NoError(t, service.RequestLink(ctx, RequestLinkRequest{Email: " Sam@Example.test "}, RequestInfo{Locale: "fr"}))
+	require.Len(t, mailer.emails, 1)
+	assert.Equal(t, "sam@example.test", mailer.emails[0].To)
+	assert.Equal(t, "fr", mailer.emails[0].Locale)
+	assert.True(t, strings.HasPrefix(mailer.emails[0].URL, "https://app.festivals.test/login/link?token="))
+
+	token := linkToken(t, mailer.emails[0])
+	session, err := service.Verify(ctx, token, RequestInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "sam@example.test", session.User.Email)
+	assert.NotEmpty(t, session.Token)
+	assert.Equal(t, []audit.AuditAction{audit.ActionUserCreate, audit.ActionLogin}, auditLog.actions)
+
+	// Links are used once
+	nonce, _, _ := strings.Cut(token, ".")
+	mockRepo.On("TakeLink", mock.Anything, nonce).Return(nil, nil).Once()
+	_, err = service.Verify(ctx, token, RequestInfo{})
+	assert.ErrorIs(t, err, ErrInvalidLink)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_VerifyRejectsForgedAndExpiredLinks(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, mailer, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	expectRequests(mockRepo, "sam@example.test", 1)
+	expectLinks(mockRepo)
+
+	require.NoError(t, service.RequestLink(ctx, RequestLinkRequest{Email: "sam@example.test"}, RequestInfo{}))
+	nonce, _, _ := strings.Cut(linkToken(t, mailer.emails[0]), ".")
+
+	_, err := service.Verify(ctx, nonce+".deadbeef", RequestInfo{})
+	assert.ErrorIs(t, err, ErrInvalidLink)
+	_, err = service.Verify(ctx, nonce, RequestInfo{})
+	assert.ErrorIs(t, err, ErrInvalidLink)
+	mockRepo.AssertNotCalled(t, "TakeLink", mock.Anything, mock.Anything)
+
+	// The forged attempts did not use the link, but it expires
+	service.now = func() time.Time { return time.Now().Add(LinkTTL + time.Second) }
+	_, err = service.Verify(ctx, linkToken(t, mailer.emails[0]), RequestInfo{})
+	assert.ErrorIs(t, err, ErrInvalidLink)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RequestLinkDoesNotRevealAccounts(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, users, mailer, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	expectRequests(mockRepo, "staff@example.test", 1)
+	expectRequests(mockRepo, "banned@example.test", 1)
+
+	users.users["staff@example.test"] = &user.User{ID: uuid.New(), Email: "staff@example.test", Role: user.UserRoleStaff}
+	users.users["banned@example.test"] = &user.User{ID: uuid.New(), Email: "banned@example.test", Role: user.UserRoleUser, Status: user.UserStatusBanned}
+
+	require.NoError(t, service.RequestLink(ctx, RequestLinkRequest{Email: "staff@example.test"}, RequestInfo{}))
+	require.NoError(t, service.RequestLink(ctx, RequestLinkRequest{Email: "banned@example.test"}, RequestInfo{}))
+	assert.Empty(t, mailer.emails)
+	mockRepo.AssertNotCalled(t, "SaveLink", mock.Anything, mock.Anything, mock.Anything)
+
+	err := service.RequestLink(ctx, RequestLinkRequest{Email: "not an email"}, RequestInfo{})
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RequestLinkIsLimitedPerEmail(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, mailer, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	expectRequests(mockRepo, "sam@example.test", MaxLinksPerEmail+2)
+	expectLinks(mockRepo)
+
+	for i := 0; i < MaxLinksPerEmail+2; i++ {
+		require.NoError(t, service.RequestLink(ctx, RequestLinkRequest{Email: "sam@example.test"}, RequestInfo{}))
+	}
+	assert.Len(t, mailer.emails, MaxLinksPerEmail)
+	mockRepo.AssertNumberOfCalls(t, "SaveLink", MaxLinksPerEmail)
+}
+
+func TestService_VerifyRefusesUsersPromotedSinceTheLink(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, users, mailer, auditLog := newTestService(mockRepo)
+	ctx := context.Background()
+	expectRequests(mockRepo, "sam@example.test", 1)
+	expectLinks(mockRepo)
+
+	require.NoError(t, service.RequestLink(ctx, RequestLinkRequest{Email: "sam@example.test"}, RequestInfo{}))
+	users.users["sam@example.test"] = &user.User{ID: uuid.New(), Email: "sam@example.test", Role: user.UserRoleOrganizer}
+
+	_, err := service.Verify(ctx, linkToken(t, mailer.emails[0]), RequestInfo{})
+	assert.ErrorIs(t, err, ErrNotAttendee)
+	assert.Equal(t, []audit.AuditAction{audit.ActionLoginFailed}, auditLog.actions)
+}
@@ -128,7 +128,7 @@ func (h *Handler) CreateOrUpdateConfig(c *gin.Context) {
 
 	var req CreateMapConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -159,7 +159,7 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 
 	var req UpdateMapConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -290,7 +290,7 @@ func (h *Handler) CreatePOI(c *gin.Context) {
 
 	var req CreatePOIRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -350,7 +350,7 @@ func (h *Handler) UpdatePOI(c *gin.Context) {
 
 	var req UpdatePOIRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -411,7 +411,7 @@ func (h *Handler) BulkCreatePOIs(c *gin.Context) {
 
 	var requests []CreatePOIRequest
 	if err := c.ShouldBindJSON(&requests); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -510,7 +510,7 @@ func (h *Handler) CreateZone(c *gin.Context) {
 
 	var req CreateZoneRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -570,7 +570,7 @@ func (h *Handler) UpdateZone(c *gin.Context) {
 
 	var req UpdateZoneRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -270,7 +270,7 @@ func (h *ArchiveHandler) DeleteMediaItem(c *gin.Context) {
 			return
 		}
 		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, http.StatusForbidden, appErr.Code, appErr.Message, nil)
+			response.SendError(c, response.NewStandardError(http.StatusForbidden, response.ErrorCode(appErr.Code), appErr.Message, nil))
 			return
 		}
 		response.InternalError(c, err.Error())
@@ -374,7 +374,7 @@ func (h *ArchiveHandler) GetMyPhotosByFestival(c *gin.Context) {
 func (h *ArchiveHandler) CreateAlbum(c *gin.Context) {
 	var req CreateAlbumRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -475,7 +475,7 @@ func (h *ArchiveHandler) UpdateAlbum(c *gin.Context) {
 
 	var req UpdateAlbumRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -534,7 +534,7 @@ func (h *ArchiveHandler) AddToAlbum(c *gin.Context) {
 
 	var req AddToAlbumRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -630,7 +630,7 @@ func (h *ArchiveHandler) GetAlbumItems(c *gin.Context) {
 func (h *ArchiveHandler) ShareMedia(c *gin.Context) {
 	var req ShareMediaRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -674,7 +674,7 @@ func (h *ArchiveHandler) GetSharedMedia(c *gin.Context) {
 			return
 		}
 		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, http.StatusGone, appErr.Code, appErr.Message, nil)
+			response.SendError(c, response.NewStandardError(http.StatusGone, response.ErrorCode(appErr.Code), appErr.Message, nil))
 			return
 		}
 		response.InternalError(c, err.Error())
@@ -880,7 +880,7 @@ func (h *ArchiveHandler) GetLikedMedia(c *gin.Context) {
 func (h *ArchiveHandler) GenerateSouvenirPDF(c *gin.Context) {
 	var req GenerateSouvenirRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -1012,7 +1012,7 @@ func (h *ArchiveHandler) ModerateMedia(c *gin.Context) {
 
 	var req ModerateMediaRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -1048,7 +1048,7 @@ func (h *ArchiveHandler) BulkModerate(c *gin.Context) {
 		Status ModerationStatus `json:"status" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
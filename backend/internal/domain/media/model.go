@@ -173,6 +173,17 @@ func DefaultImageConfig() ImageConfig {
 	}
 }
 
+// ImageVariantConfig constrains an image resized for a specific use (e.g. a festival logo)
+type ImageVariantConfig struct {
+	MaxWidth    int   // The image is scaled down to fit within MaxWidth x MaxHeight
+	MaxHeight   int
+	MinWidth    int   // Smaller images are rejected rather than upscaled
+	MinHeight   int
+	Square      bool  // Require equal width and height
+	MaxFileSize int64 // Defaults to the image upload limit when zero
+	Public      bool  // Store the object with a public-read ACL
+}
+
 // DocumentConfig holds configuration for document uploads
 type DocumentConfig struct {
 	AllowedMimeTypes []string
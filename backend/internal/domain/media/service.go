@@ -146,6 +146,114 @@ func (s *Service) UploadImage(ctx context.Context, file *multipart.FileHeader, f
 	return media, nil
 }
 
+// UploadImageVariant validates an uploaded image against a variant config, scales it
+// down to the variant bounds and stores it without thumbnails
+func (s *Service) UploadImageVariant(ctx context.Context, file *multipart.FileHeader, festivalID *uuid.UUID, uploadedBy *uuid.UUID, variant ImageVariantConfig) (*Media, error) {
+	maxFileSize := variant.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = s.imageConfig.MaxFileSize
+	}
+	if file.Size > maxFileSize {
+		return nil, errors.New("FILE_TOO_LARGE", fmt.Sprintf("Image file size exceeds maximum allowed size of %d bytes", maxFileSize))
+	}
+
+	contentType := file.Header.Get("Content-Type")
+	if !s.isAllowedImageType(contentType) {
+		return nil, errors.New("INVALID_FILE_TYPE", "File type not allowed. Allowed types: JPEG, PNG, GIF, WebP")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	fileData, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	// Check the source dimensions before resizing
+	origWidth, origHeight, err := s.imageProcessor.GetImageDimensions(bytes.NewReader(fileData))
+	if err != nil {
+		return nil, errors.New("INVALID_IMAGE", "File is not a readable image")
+	}
+	if origWidth < variant.MinWidth || origHeight < variant.MinHeight {
+		return nil, errors.New("IMAGE_TOO_SMALL", fmt.Sprintf("Image must be at least %dx%d pixels", variant.MinWidth, variant.MinHeight))
+	}
+	if variant.Square && origWidth != origHeight {
+		return nil, errors.New("IMAGE_NOT_SQUARE", "Image must be square")
+	}
+
+	opts := storage.DefaultResizeOptions()
+	opts.Width = variant.MaxWidth
+	opts.Height = variant.MaxHeight
+	opts.Quality = s.imageConfig.Quality
+
+	resizedBuf, format, err := s.imageProcessor.ResizeImage(bytes.NewReader(fileData), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resize image: %w", err)
+	}
+
+	width, height, err := s.imageProcessor.GetImageDimensions(bytes.NewReader(resizedBuf.Bytes()))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get image dimensions")
+	}
+
+	mediaID := uuid.New()
+	filename := fmt.Sprintf("%s%s", mediaID.String(), s.getExtensionForFormat(format))
+	objectKey := s.buildObjectKey(festivalID, MediaTypeImage, filename)
+
+	uploadOpts := storage.UploadOptions{
+		ContentType: storage.GetContentType(format),
+		Metadata: map[string]string{
+			"original_name": file.Filename,
+			"uploaded_by":   uploadedBy.String(),
+		},
+	}
+	if variant.Public {
+		uploadOpts.ACL = storage.ACLPublicRead
+		uploadOpts.CacheControl = "public, max-age=86400"
+	}
+
+	size := int64(resizedBuf.Len())
+	fileInfo, err := s.storage.Upload(ctx, s.defaultBucket, objectKey, resizedBuf, size, uploadOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	media := &Media{
+		ID:           mediaID,
+		FestivalID:   festivalID,
+		Type:         MediaTypeImage,
+		URL:          fileInfo.URL,
+		Filename:     filename,
+		OriginalName: file.Filename,
+		Size:         size,
+		MimeType:     storage.GetContentType(format),
+		Width:        width,
+		Height:       height,
+		Bucket:       s.defaultBucket,
+		Key:          objectKey,
+		UploadedBy:   uploadedBy,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, media); err != nil {
+		_ = s.storage.Delete(ctx, s.defaultBucket, objectKey)
+		return nil, fmt.Errorf("failed to save media record: %w", err)
+	}
+
+	log.Info().
+		Str("media_id", media.ID.String()).
+		Int("width", width).
+		Int("height", height).
+		Msg("Image variant uploaded successfully")
+
+	return media, nil
+}
+
 // UploadDocument uploads a document file
 func (s *Service) UploadDocument(ctx context.Context, file *multipart.FileHeader, festivalID *uuid.UUID, uploadedBy *uuid.UUID) (*Media, error) {
 	// Validate file size
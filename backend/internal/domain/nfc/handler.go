@@ -79,7 +79,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 func (h *Handler) ActivateTag(c *gin.Context) {
 	var req NFCActivationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -106,7 +106,7 @@ func (h *Handler) RegisterTag(c *gin.Context) {
 		FestivalID uuid.UUID `json:"festivalId" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -178,7 +178,7 @@ func (h *Handler) BlockTag(c *gin.Context) {
 
 	var req NFCBlockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -228,7 +228,7 @@ func (h *Handler) TransferTag(c *gin.Context) {
 
 	var req NFCTransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -262,7 +262,7 @@ func (h *Handler) ValidateTagForPayment(c *gin.Context) {
 		Amount int64 `json:"amount" binding:"required,min=1"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -367,7 +367,7 @@ func (h *Handler) BulkRegisterTags(c *gin.Context) {
 		UIDs []string `json:"uids" binding:"required,min=1"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -390,7 +390,7 @@ func (h *Handler) BulkRegisterTags(c *gin.Context) {
 func (h *Handler) SyncOfflineTransaction(c *gin.Context) {
 	var tx NFCTransaction
 	if err := c.ShouldBindJSON(&tx); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -412,7 +412,7 @@ func (h *Handler) SyncOfflineTransaction(c *gin.Context) {
 func (h *Handler) ActivateBracelet(c *gin.Context) {
 	var req NFCBraceletActivationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -480,7 +480,7 @@ func (h *Handler) GetBraceletInfo(c *gin.Context) {
 func (h *Handler) ProcessNFCPayment(c *gin.Context) {
 	var req NFCPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -517,7 +517,7 @@ func (h *Handler) BlockBracelet(c *gin.Context) {
 		Reason string `json:"reason" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -586,7 +586,7 @@ func (h *Handler) GetBraceletTransactions(c *gin.Context) {
 func (h *Handler) TransferBraceletBalance(c *gin.Context) {
 	var req NFCTransferBalanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -647,7 +647,7 @@ func (h *Handler) BatchActivateBracelets(c *gin.Context) {
 		UIDs []string `json:"uids" binding:"required,min=1"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -700,7 +700,7 @@ func (h *Handler) CreateBatch(c *gin.Context) {
 
 	var req NFCBatchCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
 	"gorm.io/gorm"
 )
 
@@ -468,8 +469,13 @@ func (r *repository) GetFestivalNFCStats(ctx context.Context, festivalID uuid.UU
 	stats.TotalTransactions = txStats.Count
 	stats.TotalVolume = txStats.Volume
 
-	// Get today's transactions
-	today := time.Now().Truncate(24 * time.Hour)
+	// Get today's transactions, today being the operational day of the festival
+	var festival struct {
+		Timezone    string
+		DayStartsAt string
+	}
+	r.db.WithContext(ctx).Table("festivals").Select("timezone, day_starts_at").Where("id = ?", festivalID).Scan(&festival)
+	today := tz.NewCalendar(festival.Timezone, festival.DayStartsAt).StartOfDay(time.Now())
 	var todayStats struct {
 		Count  int
 		Volume int64
@@ -78,7 +78,7 @@ func (s *Service) ActivateTag(ctx context.Context, req NFCActivationRequest, sta
 
 		// Reactivate the tag
 		existingTag.WalletID = &req.WalletID
-		existingTag.UserID = &walletObj.UserID
+		existingTag.UserID = walletObj.UserID
 		existingTag.Status = NFCStatusActive
 		existingTag.ActivatedAt = &now
 		existingTag.UpdatedAt = now
@@ -95,7 +95,7 @@ func (s *Service) ActivateTag(ctx context.Context, req NFCActivationRequest, sta
 		ID:          uuid.New(),
 		UID:         req.UID,
 		WalletID:    &req.WalletID,
-		UserID:      &walletObj.UserID,
+		UserID:      walletObj.UserID,
 		FestivalID:  req.FestivalID,
 		Status:      NFCStatusActive,
 		ActivatedAt: &now,
@@ -222,7 +222,7 @@ func (s *Service) TransferTag(ctx context.Context, uid string, req NFCTransferRe
 
 	now := time.Now()
 	tag.WalletID = &req.NewWalletID
-	tag.UserID = &newWallet.UserID
+	tag.UserID = newWallet.UserID
 	tag.Status = NFCStatusActive
 	tag.ActivatedAt = &now
 	tag.UpdatedAt = now
@@ -79,7 +79,7 @@ func (h *Handler) RegisterPushToken(c *gin.Context) {
 
 	var req RegisterPushTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -120,7 +120,7 @@ func (h *Handler) UnregisterPushToken(c *gin.Context) {
 
 	var req UnregisterPushTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -288,7 +288,7 @@ func (h *Handler) UpdatePreferences(c *gin.Context) {
 
 	var req UpdateUserPreferencesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 )
 
 // EmailClient defines the interface for sending emails
@@ -79,22 +80,18 @@ func (t EmailTemplate) String() string {
 	return string(t)
 }
 
-// GetSubject returns the default subject for each template type
+// GetSubject returns the default (English) subject for each template type
 func (t EmailTemplate) GetSubject() string {
-	subjects := map[EmailTemplate]string{
-		EmailTemplateWelcome:            "Welcome to Festivals!",
-		EmailTemplateTicketPurchased:    "Your Ticket Purchase Confirmation",
-		EmailTemplateTicketConfirmation: "Your Festival Ticket is Ready!",
-		EmailTemplateTicketTransferred:  "Ticket Transfer Notification",
-		EmailTemplateTopUpConfirmed:     "Wallet Top-Up Confirmation",
-		EmailTemplateRefundProcessed:    "Refund Processed",
-		EmailTemplatePasswordReset:      "Password Reset Request",
-		EmailTemplateSecurityAlert:      "Security Alert - Action Required",
-	}
-	if subject, ok := subjects[t]; ok {
-		return subject
+	return t.Subject(i18n.Default)
+}
+
+// Subject returns the subject of the template in the given locale
+func (t EmailTemplate) Subject(locale string) string {
+	key := "notification.email.subject." + string(t)
+	if _, ok := i18n.Lookup(i18n.Default, key); !ok {
+		key = "notification.email.subject.default"
 	}
-	return "Notification from Festivals"
+	return i18n.T(locale, key)
 }
 
 // GetTemplatePath returns the file path for the template
@@ -5,9 +5,11 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
@@ -354,7 +356,11 @@ func (s *PreferencesService) UpdatePreferences(ctx context.Context, userID uuid.
 		prefs.WeeklyDigestEnabled = *req.WeeklyDigestEnabled
 	}
 	if req.PreferredLanguage != nil {
-		prefs.PreferredLanguage = *req.PreferredLanguage
+		locale := i18n.Normalize(*req.PreferredLanguage)
+		if locale == "" {
+			return nil, fmt.Errorf("unsupported language, expected one of: %s", strings.Join(i18n.Supported, ", "))
+		}
+		prefs.PreferredLanguage = locale
 	}
 	if req.ChannelPreferences != nil {
 		// Merge channel preferences
@@ -7,10 +7,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"html/template"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/rs/zerolog/log"
 )
 
@@ -195,6 +197,8 @@ func (s *Service) SendTicketConfirmationEmailWithAttachment(ctx context.Context,
 		return nil
 	}
 
+	subject := EmailTemplateTicketConfirmation.Subject(s.recipientLocale(ctx, &userID))
+
 	// Render HTML template
 	htmlBody, err := s.renderTemplate(EmailTemplateTicketConfirmation, data)
 	if err != nil {
@@ -211,7 +215,7 @@ func (s *Service) SendTicketConfirmationEmailWithAttachment(ctx context.Context,
 		UserID:    &userID,
 		ToEmail:   toEmail,
 		Template:  EmailTemplateTicketConfirmation,
-		Subject:   EmailTemplateTicketConfirmation.GetSubject(),
+		Subject:   subject,
 		Status:    EmailLogStatusPending,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -237,7 +241,7 @@ func (s *Service) SendTicketConfirmationEmailWithAttachment(ctx context.Context,
 	// Send email with attachment
 	req := EmailSendRequest{
 		To:          []string{toEmail},
-		Subject:     EmailTemplateTicketConfirmation.GetSubject(),
+		Subject:     subject,
 		HTMLBody:    htmlBody,
 		TextBody:    textBody,
 		Attachments: attachments,
@@ -356,7 +360,11 @@ func (s *Service) UpdatePreferences(ctx context.Context, userID uuid.UUID, req U
 		prefs.QuietHoursEnd = *req.QuietHoursEnd
 	}
 	if req.PreferredLanguage != nil {
-		prefs.PreferredLanguage = *req.PreferredLanguage
+		locale := i18n.Normalize(*req.PreferredLanguage)
+		if locale == "" {
+			return nil, fmt.Errorf("unsupported language, expected one of: %s", strings.Join(i18n.Supported, ", "))
+		}
+		prefs.PreferredLanguage = locale
 	}
 
 	prefs.UpdatedAt = time.Now()
@@ -527,6 +535,8 @@ func (s *Service) sendEmail(ctx context.Context, userID *uuid.UUID, toEmail stri
 		}
 	}
 
+	subject := template.Subject(s.recipientLocale(ctx, userID))
+
 	// Render HTML template
 	htmlBody, err := s.renderTemplate(template, data)
 	if err != nil {
@@ -543,7 +553,7 @@ func (s *Service) sendEmail(ctx context.Context, userID *uuid.UUID, toEmail stri
 		UserID:    userID,
 		ToEmail:   toEmail,
 		Template:  template,
-		Subject:   template.GetSubject(),
+		Subject:   subject,
 		Status:    EmailLogStatusPending,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -559,7 +569,7 @@ func (s *Service) sendEmail(ctx context.Context, userID *uuid.UUID, toEmail stri
 	}
 
 	// Send email
-	result, err := s.emailClient.SendEmail(ctx, toEmail, template.GetSubject(), htmlBody, textBody)
+	result, err := s.emailClient.SendEmail(ctx, toEmail, subject, htmlBody, textBody)
 	if err != nil {
 		emailLog.Status = EmailLogStatusFailed
 		emailLog.Error = err.Error()
@@ -585,6 +595,18 @@ func (s *Service) sendEmail(ctx context.Context, userID *uuid.UUID, toEmail stri
 	return nil
 }
 
+// recipientLocale returns the preferred language of the recipient, else the locale of the request
+func (s *Service) recipientLocale(ctx context.Context, userID *uuid.UUID) string {
+	if userID != nil {
+		if prefs, err := s.GetUserPreferences(ctx, *userID); err == nil && prefs != nil {
+			if locale := i18n.Normalize(prefs.PreferredLanguage); locale != "" {
+				return locale
+			}
+		}
+	}
+	return i18n.FromContext(ctx)
+}
+
 // renderTemplate renders an email template with the given data
 func (s *Service) renderTemplate(template EmailTemplate, data interface{}) (string, error) {
 	templatePath := template.GetTemplatePath()
@@ -67,7 +67,7 @@ func (h *SMSHandler) SendBroadcast(c *gin.Context) {
 
 	var req SendBroadcastRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -241,61 +241,62 @@ type SMSTemplateInfo struct {
 // @Success 200 {array} SMSTemplateInfo
 // @Router /festivals/{festivalId}/sms/templates [get]
 func (h *SMSHandler) GetTemplates(c *gin.Context) {
+	locale := response.Locale(c)
 	templates := []SMSTemplateInfo{
 		{
 			ID:          SMSTemplateTicketReminder,
 			Name:        "Ticket Reminder",
 			Description: "Reminder sent before the festival starts",
-			Content:     SMSTemplateContent[SMSTemplateTicketReminder],
+			Content:     SMSTemplateTicketReminder.Content(locale),
 			Variables:   []string{"name", "festivalName", "date"},
 		},
 		{
 			ID:          SMSTemplateSOSConfirmation,
 			Name:        "SOS Confirmation",
 			Description: "Confirmation when user sends SOS alert",
-			Content:     SMSTemplateContent[SMSTemplateSOSConfirmation],
+			Content:     SMSTemplateSOSConfirmation.Content(locale),
 			Variables:   []string{},
 		},
 		{
 			ID:          SMSTemplateTopUpConfirmation,
 			Name:        "Top-Up Confirmation",
 			Description: "Confirmation when wallet is topped up",
-			Content:     SMSTemplateContent[SMSTemplateTopUpConfirmation],
+			Content:     SMSTemplateTopUpConfirmation.Content(locale),
 			Variables:   []string{"name", "amount", "balance", "festivalName"},
 		},
 		{
 			ID:          SMSTemplateBroadcast,
 			Name:        "Broadcast",
 			Description: "Custom message to all participants",
-			Content:     SMSTemplateContent[SMSTemplateBroadcast],
+			Content:     SMSTemplateBroadcast.Content(locale),
 			Variables:   []string{"message"},
 		},
 		{
 			ID:          SMSTemplateWelcome,
 			Name:        "Welcome",
 			Description: "Welcome message when user arrives",
-			Content:     SMSTemplateContent[SMSTemplateWelcome],
+			Content:     SMSTemplateWelcome.Content(locale),
 			Variables:   []string{"festivalName"},
 		},
 		{
 			ID:          SMSTemplateLineupChange,
 			Name:        "Lineup Change",
 			Description: "Notification about lineup changes",
-			Content:     SMSTemplateContent[SMSTemplateLineupChange],
+			Content:     SMSTemplateLineupChange.Content(locale),
 			Variables:   []string{"festivalName", "message"},
 		},
 		{
 			ID:          SMSTemplateEmergency,
 			Name:        "Emergency",
 			Description: "Emergency notification to all participants",
-			Content:     SMSTemplateContent[SMSTemplateEmergency],
+			Content:     SMSTemplateEmergency.Content(locale),
 			Variables:   []string{"festivalName", "message"},
 		},
 		{
 			ID:          SMSTemplatePaymentConfirm,
 			Name:        "Payment Confirmation",
 			Description: "Confirmation after payment",
-			Content:     SMSTemplateContent[SMSTemplatePaymentConfirm],
+			Content:     SMSTemplatePaymentConfirm.Content(locale),
 			Variables:   []string{"amount", "transactionId", "festivalName"},
 		},
 	}
@@ -347,7 +348,7 @@ type SendSingleSMSRequest struct {
 func (h *SMSHandler) SendSingleSMS(c *gin.Context) {
 	var req SendSingleSMSRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -405,7 +406,7 @@ type OptOutRequest struct {
 func (h *SMSHandler) OptOut(c *gin.Context) {
 	var req OptOutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -441,7 +442,7 @@ type OptInRequest struct {
 func (h *SMSHandler) OptIn(c *gin.Context) {
 	var req OptInRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
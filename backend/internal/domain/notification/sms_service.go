@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/sms"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
@@ -26,16 +27,14 @@ const (
 	SMSTemplatePaymentConfirm     SMSTemplate = "PAYMENT_CONFIRM"
 )
 
-// SMSTemplateContent contains predefined templates with variable placeholders
-var SMSTemplateContent = map[SMSTemplate]string{
-	SMSTemplateTicketReminder:    "Hi {{name}}! Reminder: {{festivalName}} starts on {{date}}. Don't forget your ticket! See you there!",
-	SMSTemplateSOSConfirmation:   "Your SOS alert has been received. Help is on the way. Stay calm and remain at your current location if safe.",
-	SMSTemplateTopUpConfirmation: "Hi {{name}}! Your wallet has been topped up with {{amount}}. New balance: {{balance}}. Enjoy {{festivalName}}!",
-	SMSTemplateBroadcast:         "{{message}}",
-	SMSTemplateWelcome:           "Welcome to {{festivalName}}! We're excited to have you. Check the app for schedules and updates. Enjoy!",
-	SMSTemplateLineupChange:      "Lineup update for {{festivalName}}: {{message}}. Check the app for the full schedule.",
-	SMSTemplateEmergency:         "URGENT - {{festivalName}}: {{message}}. Please follow staff instructions.",
-	SMSTemplatePaymentConfirm:    "Payment confirmed! Amount: {{amount}}. Transaction: {{transactionId}}. Thank you for your purchase at {{festivalName}}!",
+// Content returns the text of the template in the given locale from the message
+// catalogs, with {variable} placeholders substituted from params
+func (t SMSTemplate) Content(locale string, params ...i18n.Params) string {
+	key := "notification.sms." + string(t)
+	if _, ok := i18n.Lookup(i18n.Default, key); !ok {
+		return ""
+	}
+	return i18n.T(locale, key, params...)
 }
 
 // SMSLogStatus represents the status of an SMS log entry
@@ -349,8 +348,8 @@ func (s *SMSService) sendTemplatedSMS(ctx context.Context, festivalID, userID *u
 		return nil
 	}
 
-	// Render template
-	message := s.renderTemplate(template, variables)
+	// Render template in the language of the request
+	message := s.renderTemplate(i18n.FromContext(ctx), template, variables)
 
 	// Send SMS
 	result, err := s.smsClient.SendSMS(ctx, phone, message)
@@ -369,20 +368,13 @@ func (s *SMSService) sendTemplatedSMS(ctx context.Context, festivalID, userID *u
 	return nil
 }
 
-// renderTemplate renders an SMS template with variables
-func (s *SMSService) renderTemplate(template SMSTemplate, variables SMSVariables) string {
-	content, ok := SMSTemplateContent[template]
-	if !ok {
-		return ""
-	}
-
-	message := content
-	for key, value := range variables {
-		placeholder := "{{" + key + "}}"
-		message = strings.ReplaceAll(message, placeholder, value)
+// renderTemplate renders an SMS template with variables in the given locale
+func (s *SMSService) renderTemplate(locale string, template SMSTemplate, variables SMSVariables) string {
+	params := i18n.Params{}
+	for name, value := range variables {
+		params[name] = value
 	}
-
-	return message
+	return template.Content(locale, params)
 }
 
 // logSMS creates an SMS log entry
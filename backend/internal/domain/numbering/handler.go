@@ -0,0 +1,269 @@
+package numbering
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped document numbering routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	numbering := r.Group("/numbering")
+	{
+		numbering.GET("", h.ListFormats)
+		numbering.PUT("/:type", h.UpdateFormat)
+		numbering.POST("/:type/allocate", h.Allocate)
+		numbering.GET("/:type/numbers", h.ListNumbers)
+		numbering.GET("/:type/audit", h.Audit)
+	}
+}
+
+// ListFormats lists the number formats of the festival
+// @Summary List number formats
+// @Description List the number template and current sequence value of every document type
+// @Tags numbering
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]FormatResponse} "Number formats"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/numbering [get]
+func (h *Handler) ListFormats(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	formats, err := h.service.ListFormats(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, formats)
+}
+
+// UpdateFormat updates the number format of a document type
+// @Summary Update number format
+// @Description Set the prefix, template and zero padding of a document type. Templates use {prefix}, {year} or {yy}, and {seq}; numbers already allocated are not renumbered
+// @Tags numbering
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param type path string true "Document type" Enums(invoice, credit_note, receipt, settlement, z_report)
+// @Param request body UpdateFormatRequest true "Format changes"
+// @Success 200 {object} response.Response{data=FormatResponse} "Format updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid format"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/numbering/{type} [put]
+func (h *Handler) UpdateFormat(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	docType, err := ParseDocumentType(c.Param("type"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var req UpdateFormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	format, err := h.service.UpdateFormat(c.Request.Context(), festivalID, docType, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, format)
+}
+
+// Allocate allocates the next number of a document type
+// @Summary Allocate document number
+// @Description Hand out the next gapless number of a document type for the current year in the festival's timezone
+// @Tags numbering
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param type path string true "Document type" Enums(invoice, credit_note, receipt, settlement, z_report)
+// @Param request body AllocateRequest false "Numbered document"
+// @Success 201 {object} response.Response{data=AllocatedNumber} "Number allocated"
+// @Failure 400 {object} response.ErrorResponse "Unknown document type"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Failure 409 {object} response.ErrorResponse "Format produces an existing number"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/numbering/{type}/allocate [post]
+func (h *Handler) Allocate(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	docType, err := ParseDocumentType(c.Param("type"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var req AllocateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	number, err := h.service.Allocate(c.Request.Context(), festivalID, docType, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, number)
+}
+
+// ListNumbers lists the numbers allocated for a document type
+// @Summary List allocated numbers
+// @Description List the numbers allocated for a document type in a year, latest first
+// @Tags numbering
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param type path string true "Document type" Enums(invoice, credit_note, receipt, settlement, z_report)
+// @Param year query int false "Year, the current year in the festival's timezone by default"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Success 200 {object} response.Response{data=[]AllocatedNumber} "Allocated numbers"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/numbering/{type}/numbers [get]
+func (h *Handler) ListNumbers(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	docType, err := ParseDocumentType(c.Param("type"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var query ListNumbersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	numbers, total, err := h.service.ListNumbers(c.Request.Context(), festivalID, docType, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, numbers, &response.Meta{
+		Total:   int(total),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// Audit checks the numbers of a document type for gaps
+// @Summary Audit number sequence
+// @Description Check that every sequence value of a document type and year up to its counter has an allocated number
+// @Tags numbering
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param type path string true "Document type" Enums(invoice, credit_note, receipt, settlement, z_report)
+// @Param year query int false "Year, the current year in the festival's timezone by default"
+// @Success 200 {object} response.Response{data=AuditReport} "Audit report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/numbering/{type}/audit [get]
+func (h *Handler) Audit(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	docType, err := ParseDocumentType(c.Param("type"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var year int
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err = strconv.Atoi(yearStr)
+		if err != nil {
+			h.handleError(c, ErrInvalidYear)
+			return
+		}
+	}
+
+	report, err := h.service.Audit(c.Request.Context(), festivalID, docType, year)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrInvalidDocumentType):
+		response.BadRequest(c, "INVALID_DOCUMENT_TYPE", "Document type must be invoice, credit_note, receipt or settlement", nil)
+	case errors.Is(err, ErrInvalidTemplate):
+		response.BadRequest(c, "INVALID_TEMPLATE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidPrefix):
+		response.BadRequest(c, "INVALID_PREFIX", err.Error(), nil)
+	case errors.Is(err, ErrInvalidPadding):
+		response.BadRequest(c, "INVALID_PADDING", err.Error(), nil)
+	case errors.Is(err, ErrInvalidYear):
+		response.BadRequest(c, "INVALID_YEAR", "Year must be between 2000 and the current year", nil)
+	case errors.Is(err, ErrNumberConflict):
+		response.Conflict(c, "NUMBER_CONFLICT", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
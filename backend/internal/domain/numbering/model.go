@@ -0,0 +1,276 @@
+package numbering
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Numbering errors
+var (
+	ErrFestivalNotFound    = errors.New("festival not found")
+	ErrInvalidDocumentType = errors.New("unknown document type")
+	ErrInvalidTemplate     = errors.New("invalid number template")
+	ErrInvalidPrefix       = errors.New("prefix may only contain letters, digits, '-', '_' and '/' (at most 16 characters)")
+	ErrInvalidPadding      = errors.New("padding must be between 1 and 12")
+	ErrInvalidYear         = errors.New("invalid year")
+	ErrNumberConflict      = errors.New("number template produces a number that was already allocated")
+)
+
+// Template placeholders
+const (
+	PlaceholderPrefix    = "{prefix}"
+	PlaceholderYear      = "{year}" // Four-digit year, e.g. 2025
+	PlaceholderShortYear = "{yy}"   // Two-digit year, e.g. 25
+	PlaceholderSequence  = "{seq}"  // Sequence number, zero padded
+)
+
+// DefaultTemplate is the number template of document types without a saved format
+const DefaultTemplate = PlaceholderPrefix + "-" + PlaceholderYear + "-" + PlaceholderSequence
+
+// DefaultPadding is the sequence width of document types without a saved format
+const DefaultPadding = 6
+
+// MaxPadding bounds the zero padding of the sequence
+const MaxPadding = 12
+
+// MaxNumberLength bounds the length of a rendered number
+const MaxNumberLength = 64
+
+// DocumentType is a kind of document numbered by the service; each type has its
+// own sequence per festival and per year
+type DocumentType string
+
+const (
+	DocumentTypeInvoice    DocumentType = "INVOICE"
+	DocumentTypeCreditNote DocumentType = "CREDIT_NOTE"
+	DocumentTypeReceipt    DocumentType = "RECEIPT"
+	DocumentTypeSettlement DocumentType = "SETTLEMENT"
+	DocumentTypeZReport    DocumentType = "Z_REPORT" // End-of-day close report
+)
+
+// DocumentTypes lists the numbered document types
+var DocumentTypes = []DocumentType{
+	DocumentTypeInvoice,
+	DocumentTypeCreditNote,
+	DocumentTypeReceipt,
+	DocumentTypeSettlement,
+	DocumentTypeZReport,
+}
+
+// ParseDocumentType converts a path parameter like "invoice" or "CREDIT_NOTE" to a DocumentType
+func ParseDocumentType(s string) (DocumentType, error) {
+	docType := DocumentType(strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), "-", "_")))
+	if !docType.IsValid() {
+		return "", ErrInvalidDocumentType
+	}
+	return docType, nil
+}
+
+// IsValid checks if the document type is valid
+func (t DocumentType) IsValid() bool {
+	switch t {
+	case DocumentTypeInvoice, DocumentTypeCreditNote, DocumentTypeReceipt, DocumentTypeSettlement, DocumentTypeZReport:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultPrefix returns the prefix of the document type without a saved format
+func (t DocumentType) DefaultPrefix() string {
+	switch t {
+	case DocumentTypeInvoice:
+		return "INV"
+	case DocumentTypeCreditNote:
+		return "CN"
+	case DocumentTypeReceipt:
+		return "RCP"
+	case DocumentTypeSettlement:
+		return "STL"
+	case DocumentTypeZReport:
+		return "Z"
+	default:
+		return ""
+	}
+}
+
+// Format is the number template of a document type for a festival
+type Format struct {
+	FestivalID   uuid.UUID    `json:"festivalId" gorm:"type:uuid;primary_key"`
+	DocumentType DocumentType `json:"documentType" gorm:"primary_key"`
+	Prefix       string       `json:"prefix"`
+	Template     string       `json:"template"`
+	Padding      int          `json:"padding"`
+	UpdatedBy    *uuid.UUID   `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+}
+
+func (Format) TableName() string {
+	return "numbering_formats"
+}
+
+// DefaultFormat returns the format used for a document type until one is saved
+func DefaultFormat(festivalID uuid.UUID, docType DocumentType) Format {
+	return Format{
+		FestivalID:   festivalID,
+		DocumentType: docType,
+		Prefix:       docType.DefaultPrefix(),
+		Template:     DefaultTemplate,
+		Padding:      DefaultPadding,
+	}
+}
+
+// Render builds the number of a sequence value allocated in a year,
+// e.g. "INV-2025-000042" with the default template
+func (f *Format) Render(year int, sequence int64) string {
+	return strings.NewReplacer(
+		PlaceholderPrefix, f.Prefix,
+		PlaceholderYear, fmt.Sprintf("%04d", year),
+		PlaceholderShortYear, fmt.Sprintf("%02d", year%100),
+		PlaceholderSequence, fmt.Sprintf("%0*d", f.Padding, sequence),
+	).Replace(f.Template)
+}
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	prefixPattern      = regexp.MustCompile(`^[A-Za-z0-9/_-]{0,16}$`)
+	literalPattern     = regexp.MustCompile(`^[A-Za-z0-9/_.\- ]*$`)
+)
+
+// Validate checks the prefix, padding and template of a format. Sequences restart
+// every year, so the template must contain the year to keep numbers unique.
+func (f *Format) Validate() error {
+	if !prefixPattern.MatchString(f.Prefix) {
+		return ErrInvalidPrefix
+	}
+	if f.Padding < 1 || f.Padding > MaxPadding {
+		return ErrInvalidPadding
+	}
+	return ValidateTemplate(f.Template)
+}
+
+// ValidateTemplate checks that a template only uses known placeholders and safe
+// literal characters, and contains the sequence exactly once and the year
+func ValidateTemplate(template string) error {
+	if template == "" || len(template) > MaxNumberLength {
+		return fmt.Errorf("%w: must be 1 to %d characters", ErrInvalidTemplate, MaxNumberLength)
+	}
+
+	for _, placeholder := range placeholderPattern.FindAllString(template, -1) {
+		switch placeholder {
+		case PlaceholderPrefix, PlaceholderYear, PlaceholderShortYear, PlaceholderSequence:
+		default:
+			return fmt.Errorf("%w: unknown placeholder %s", ErrInvalidTemplate, placeholder)
+		}
+	}
+	if literals := placeholderPattern.ReplaceAllString(template, ""); !literalPattern.MatchString(literals) {
+		return fmt.Errorf("%w: only letters, digits, spaces and - _ / . are allowed outside placeholders", ErrInvalidTemplate)
+	}
+
+	if strings.Count(template, PlaceholderSequence) != 1 {
+		return fmt.Errorf("%w: must contain %s exactly once", ErrInvalidTemplate, PlaceholderSequence)
+	}
+	if !strings.Contains(template, PlaceholderYear) && !strings.Contains(template, PlaceholderShortYear) {
+		return fmt.Errorf("%w: must contain %s or %s since sequences restart every year", ErrInvalidTemplate, PlaceholderYear, PlaceholderShortYear)
+	}
+	return nil
+}
+
+// Sequence is the counter of a document type for a festival and a year
+type Sequence struct {
+	FestivalID   uuid.UUID    `json:"festivalId" gorm:"type:uuid;primary_key"`
+	DocumentType DocumentType `json:"documentType" gorm:"primary_key"`
+	Year         int          `json:"year" gorm:"primary_key"`
+	LastValue    int64        `json:"lastValue"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+}
+
+func (Sequence) TableName() string {
+	return "number_sequences"
+}
+
+// AllocatedNumber is the append-only record of a number handed out to a document
+type AllocatedNumber struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key"`
+	FestivalID    uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null"`
+	DocumentType  DocumentType `json:"documentType" gorm:"not null"`
+	Year          int          `json:"year" gorm:"not null"`
+	Sequence      int64        `json:"sequence" gorm:"not null"`
+	Number        string       `json:"number" gorm:"not null"`
+	ReferenceType string       `json:"referenceType,omitempty"` // Kind of the numbered document, e.g. "order"
+	ReferenceID   string       `json:"referenceId,omitempty"`   // ID of the numbered document
+	AllocatedBy   *uuid.UUID   `json:"allocatedBy,omitempty" gorm:"type:uuid"`
+	AllocatedAt   time.Time    `json:"allocatedAt"`
+}
+
+func (AllocatedNumber) TableName() string {
+	return "allocated_numbers"
+}
+
+// AllocateRequest represents a request to number a document
+type AllocateRequest struct {
+	ReferenceType string `json:"referenceType,omitempty" binding:"omitempty,max=50"`
+	ReferenceID   string `json:"referenceId,omitempty" binding:"omitempty,max=100"`
+}
+
+// UpdateFormatRequest represents a format update; omitted fields are left unchanged
+type UpdateFormatRequest struct {
+	Prefix   *string `json:"prefix,omitempty"`
+	Template *string `json:"template,omitempty"`
+	Padding  *int    `json:"padding,omitempty"`
+}
+
+// ListNumbersQuery represents the query parameters for listing allocated numbers
+type ListNumbersQuery struct {
+	Year    int `form:"year" binding:"omitempty,min=2000,max=9999"`
+	Page    int `form:"page,default=1" binding:"min=1"`
+	PerPage int `form:"per_page,default=50" binding:"min=1,max=200"`
+}
+
+// FormatResponse is a document type's format with its current sequence value
+type FormatResponse struct {
+	DocumentType DocumentType `json:"documentType"`
+	Prefix       string       `json:"prefix"`
+	Template     string       `json:"template"`
+	Padding      int          `json:"padding"`
+	Example      string       `json:"example"`   // Next number of the current year
+	Year         int          `json:"year"`      // Current year in the festival's timezone
+	LastValue    int64        `json:"lastValue"` // Last sequence value allocated this year, 0 if none
+	IsDefault    bool         `json:"isDefault"`
+	UpdatedAt    *time.Time   `json:"updatedAt,omitempty"`
+}
+
+// ToResponse converts a format to API response format with the sequence value of a year
+func (f *Format) ToResponse(year int, lastValue int64) FormatResponse {
+	response := FormatResponse{
+		DocumentType: f.DocumentType,
+		Prefix:       f.Prefix,
+		Template:     f.Template,
+		Padding:      f.Padding,
+		Example:      f.Render(year, lastValue+1),
+		Year:         year,
+		LastValue:    lastValue,
+		IsDefault:    f.CreatedAt.IsZero(),
+	}
+	if !f.UpdatedAt.IsZero() {
+		updatedAt := f.UpdatedAt
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
+
+// AuditReport checks that the numbers of a document type and year form an unbroken sequence
+type AuditReport struct {
+	DocumentType DocumentType `json:"documentType"`
+	Year         int          `json:"year"`
+	LastValue    int64        `json:"lastValue"` // Counter value of the sequence
+	Allocated    int64        `json:"allocated"` // Numbers recorded for the year
+	Missing      []int64      `json:"missing"`   // Sequence values below LastValue without a record
+	Gapless      bool         `json:"gapless"`
+}
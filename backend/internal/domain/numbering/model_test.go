@@ -0,0 +1,87 @@
+package numbering
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseDocumentType tests parsing document types from path parameters
+func TestParseDocumentType(t *testing.T) {
+	tests := map[string]DocumentType{
+		"invoice":     DocumentTypeInvoice,
+		"INVOICE":     DocumentTypeInvoice,
+		"credit-note": DocumentTypeCreditNote,
+		"credit_note": DocumentTypeCreditNote,
+		"Receipt":     DocumentTypeReceipt,
+		"settlement":  DocumentTypeSettlement,
+		"z-report":    DocumentTypeZReport,
+	}
+
+	for input, expected := range tests {
+		got, err := ParseDocumentType(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	_, err := ParseDocumentType("quote")
+	assert.ErrorIs(t, err, ErrInvalidDocumentType)
+}
+
+// TestFormat_Render tests rendering numbers from templates
+func TestFormat_Render(t *testing.T) {
+	format := DefaultFormat(uuid.New(), DocumentTypeInvoice)
+	assert.Equal(t, "INV-2025-000042", format.Render(2025, 42))
+
+	format = Format{Prefix: "RW", Template: "{prefix}/{yy}/{seq}", Padding: 4}
+	assert.Equal(t, "RW/25/0007", format.Render(2025, 7))
+
+	// Sequences wider than the padding are not truncated
+	assert.Equal(t, "RW/25/123456", format.Render(2025, 123456))
+}
+
+// TestFormat_Validate tests validation of prefixes, padding and templates
+func TestFormat_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		wantErr error
+	}{
+		{"default", Format{Prefix: "INV", Template: DefaultTemplate, Padding: 6}, nil},
+		{"short year and literals", Format{Prefix: "", Template: "F {yy}.{seq}", Padding: 3}, nil},
+		{"prefix with space", Format{Prefix: "IN V", Template: DefaultTemplate, Padding: 6}, ErrInvalidPrefix},
+		{"prefix too long", Format{Prefix: "ABCDEFGHIJKLMNOPQ", Template: DefaultTemplate, Padding: 6}, ErrInvalidPrefix},
+		{"zero padding", Format{Prefix: "INV", Template: DefaultTemplate, Padding: 0}, ErrInvalidPadding},
+		{"padding too wide", Format{Prefix: "INV", Template: DefaultTemplate, Padding: 13}, ErrInvalidPadding},
+		{"empty template", Format{Prefix: "INV", Template: "", Padding: 6}, ErrInvalidTemplate},
+		{"missing sequence", Format{Prefix: "INV", Template: "{prefix}-{year}", Padding: 6}, ErrInvalidTemplate},
+		{"sequence twice", Format{Prefix: "INV", Template: "{seq}-{year}-{seq}", Padding: 6}, ErrInvalidTemplate},
+		{"missing year", Format{Prefix: "INV", Template: "{prefix}-{seq}", Padding: 6}, ErrInvalidTemplate},
+		{"unknown placeholder", Format{Prefix: "INV", Template: "{prefix}-{month}-{year}-{seq}", Padding: 6}, ErrInvalidTemplate},
+		{"unsafe literal", Format{Prefix: "INV", Template: "<{year}>{seq}", Padding: 6}, ErrInvalidTemplate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.format.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+// TestFormat_ToResponse tests the next number example of a format
+func TestFormat_ToResponse(t *testing.T) {
+	format := DefaultFormat(uuid.New(), DocumentTypeSettlement)
+
+	resp := format.ToResponse(2025, 9)
+	assert.Equal(t, "STL-2025-000010", resp.Example)
+	assert.Equal(t, int64(9), resp.LastValue)
+	assert.True(t, resp.IsDefault)
+	assert.Nil(t, resp.UpdatedAt)
+}
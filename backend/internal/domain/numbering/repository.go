@@ -0,0 +1,204 @@
+package numbering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxMissingReported bounds the missing sequence values listed by an audit
+const maxMissingReported = 1000
+
+type Repository interface {
+	// WithTx returns a repository running its queries in tx, so a number can be
+	// allocated in the transaction that creates the numbered document
+	WithTx(tx *gorm.DB) Repository
+
+	GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (*string, error)
+	GetFormat(ctx context.Context, festivalID uuid.UUID, docType DocumentType) (*Format, error)
+	ListFormats(ctx context.Context, festivalID uuid.UUID) ([]Format, error)
+	SaveFormat(ctx context.Context, format *Format) error
+
+	Allocate(ctx context.Context, format *Format, number *AllocatedNumber) error
+	GetSequence(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int) (*Sequence, error)
+	ListSequences(ctx context.Context, festivalID uuid.UUID, year int) ([]Sequence, error)
+	ListNumbers(ctx context.Context, festivalID uuid.UUID, docType DocumentType, query ListNumbersQuery) ([]AllocatedNumber, int64, error)
+	CountNumbers(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int) (int64, error)
+	ListMissingSequences(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int, lastValue int64) ([]int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (*string, error) {
+	var timezones []string
+	err := r.db.WithContext(ctx).
+		Table("public.festivals").
+		Select("timezone").
+		Where("id = ?", festivalID).
+		Limit(1).
+		Scan(&timezones).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(timezones) == 0 {
+		return nil, nil
+	}
+	return &timezones[0], nil
+}
+
+func (r *repository) GetFormat(ctx context.Context, festivalID uuid.UUID, docType DocumentType) (*Format, error) {
+	var format Format
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND document_type = ?", festivalID, docType).
+		First(&format).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get numbering format: %w", err)
+	}
+	return &format, nil
+}
+
+func (r *repository) ListFormats(ctx context.Context, festivalID uuid.UUID) ([]Format, error) {
+	var formats []Format
+	if err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).Find(&formats).Error; err != nil {
+		return nil, fmt.Errorf("failed to list numbering formats: %w", err)
+	}
+	return formats, nil
+}
+
+// SaveFormat inserts or replaces the format of a document type
+func (r *repository) SaveFormat(ctx context.Context, format *Format) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "festival_id"}, {Name: "document_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"prefix", "template", "padding", "updated_by", "updated_at"}),
+	}).Create(format).Error
+	if err != nil {
+		return fmt.Errorf("failed to save numbering format: %w", err)
+	}
+	return nil
+}
+
+// Allocate takes the next value of the sequence of the number's festival, document
+// type and year, renders it with format and records the number. The upsert locks
+// the counter row until the transaction ends: concurrent allocations wait for it,
+// and when the transaction rolls back the value is handed out again, so the
+// recorded numbers have no gaps.
+func (r *repository) Allocate(ctx context.Context, format *Format, number *AllocatedNumber) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sequence int64
+		err := tx.Raw(`
+			INSERT INTO number_sequences (festival_id, document_type, year, last_value, updated_at)
+			VALUES (?, ?, ?, 1, NOW())
+			ON CONFLICT (festival_id, document_type, year)
+			DO UPDATE SET last_value = number_sequences.last_value + 1, updated_at = NOW()
+			RETURNING last_value`,
+			number.FestivalID, number.DocumentType, number.Year,
+		).Scan(&sequence).Error
+		if err != nil {
+			return fmt.Errorf("failed to increment number sequence: %w", err)
+		}
+
+		number.Sequence = sequence
+		number.Number = format.Render(number.Year, sequence)
+
+		if err := tx.Create(number).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return ErrNumberConflict
+			}
+			return fmt.Errorf("failed to record allocated number: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetSequence(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int) (*Sequence, error) {
+	var sequence Sequence
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND document_type = ? AND year = ?", festivalID, docType, year).
+		First(&sequence).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get number sequence: %w", err)
+	}
+	return &sequence, nil
+}
+
+func (r *repository) ListSequences(ctx context.Context, festivalID uuid.UUID, year int) ([]Sequence, error) {
+	var sequences []Sequence
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND year = ?", festivalID, year).
+		Find(&sequences).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list number sequences: %w", err)
+	}
+	return sequences, nil
+}
+
+func (r *repository) ListNumbers(ctx context.Context, festivalID uuid.UUID, docType DocumentType, query ListNumbersQuery) ([]AllocatedNumber, int64, error) {
+	var numbers []AllocatedNumber
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&AllocatedNumber{}).
+		Where("festival_id = ? AND document_type = ? AND year = ?", festivalID, docType, query.Year)
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count allocated numbers: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	if err := q.Offset(offset).Limit(query.PerPage).Order("sequence DESC").Find(&numbers).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list allocated numbers: %w", err)
+	}
+
+	return numbers, total, nil
+}
+
+func (r *repository) CountNumbers(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&AllocatedNumber{}).
+		Where("festival_id = ? AND document_type = ? AND year = ?", festivalID, docType, year).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count allocated numbers: %w", err)
+	}
+	return count, nil
+}
+
+// ListMissingSequences lists the sequence values up to lastValue without an allocated number
+func (r *repository) ListMissingSequences(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int, lastValue int64) ([]int64, error) {
+	var missing []int64
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT s.value
+		FROM generate_series(1::bigint, ?::bigint) AS s(value)
+		LEFT JOIN allocated_numbers n
+			ON n.festival_id = ? AND n.document_type = ? AND n.year = ? AND n.sequence = s.value
+		WHERE n.id IS NULL
+		ORDER BY s.value
+		LIMIT ?`,
+		lastValue, festivalID, docType, year, maxMissingReported,
+	).Scan(&missing).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list missing sequences: %w", err)
+	}
+	return missing, nil
+}
@@ -0,0 +1,242 @@
+package numbering
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Allocate hands out the next number of a document type. The number is recorded
+// even if the document is never created; callers creating the document in a
+// transaction should use AllocateTx so that a failure releases the number.
+func (s *Service) Allocate(ctx context.Context, festivalID uuid.UUID, docType DocumentType, allocatedBy *uuid.UUID, req AllocateRequest) (*AllocatedNumber, error) {
+	return s.allocate(ctx, s.repo, festivalID, docType, allocatedBy, req)
+}
+
+// AllocateTx hands out the next number of a document type within tx; the number
+// is only taken if tx commits, which keeps the sequence gapless
+func (s *Service) AllocateTx(ctx context.Context, tx *gorm.DB, festivalID uuid.UUID, docType DocumentType, allocatedBy *uuid.UUID, req AllocateRequest) (*AllocatedNumber, error) {
+	return s.allocate(ctx, s.repo.WithTx(tx), festivalID, docType, allocatedBy, req)
+}
+
+func (s *Service) allocate(ctx context.Context, repo Repository, festivalID uuid.UUID, docType DocumentType, allocatedBy *uuid.UUID, req AllocateRequest) (*AllocatedNumber, error) {
+	if !docType.IsValid() {
+		return nil, ErrInvalidDocumentType
+	}
+
+	now, err := s.festivalNow(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := s.getFormat(ctx, festivalID, docType)
+	if err != nil {
+		return nil, err
+	}
+
+	number := &AllocatedNumber{
+		ID:            uuid.New(),
+		FestivalID:    festivalID,
+		DocumentType:  docType,
+		Year:          now.Year(),
+		ReferenceType: strings.TrimSpace(req.ReferenceType),
+		ReferenceID:   strings.TrimSpace(req.ReferenceID),
+		AllocatedBy:   allocatedBy,
+		AllocatedAt:   now,
+	}
+	if err := repo.Allocate(ctx, format, number); err != nil {
+		return nil, err
+	}
+	return number, nil
+}
+
+// ListFormats returns the format and current sequence value of every document type
+func (s *Service) ListFormats(ctx context.Context, festivalID uuid.UUID) ([]FormatResponse, error) {
+	now, err := s.festivalNow(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.ListFormats(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	formats := make(map[DocumentType]*Format, len(saved))
+	for i := range saved {
+		formats[saved[i].DocumentType] = &saved[i]
+	}
+
+	sequences, err := s.repo.ListSequences(ctx, festivalID, now.Year())
+	if err != nil {
+		return nil, err
+	}
+	lastValues := make(map[DocumentType]int64, len(sequences))
+	for _, sequence := range sequences {
+		lastValues[sequence.DocumentType] = sequence.LastValue
+	}
+
+	responses := make([]FormatResponse, len(DocumentTypes))
+	for i, docType := range DocumentTypes {
+		format := formats[docType]
+		if format == nil {
+			defaultFormat := DefaultFormat(festivalID, docType)
+			format = &defaultFormat
+		}
+		responses[i] = format.ToResponse(now.Year(), lastValues[docType])
+	}
+	return responses, nil
+}
+
+// UpdateFormat validates and saves the format of a document type. Numbers already
+// allocated keep their value; new numbers use the new format.
+func (s *Service) UpdateFormat(ctx context.Context, festivalID uuid.UUID, docType DocumentType, updatedBy *uuid.UUID, req UpdateFormatRequest) (*FormatResponse, error) {
+	if !docType.IsValid() {
+		return nil, ErrInvalidDocumentType
+	}
+
+	now, err := s.festivalNow(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := s.getFormat(ctx, festivalID, docType)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Prefix != nil {
+		format.Prefix = strings.TrimSpace(*req.Prefix)
+	}
+	if req.Template != nil {
+		format.Template = strings.TrimSpace(*req.Template)
+	}
+	if req.Padding != nil {
+		format.Padding = *req.Padding
+	}
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	if format.CreatedAt.IsZero() {
+		format.CreatedAt = now
+	}
+	format.UpdatedAt = now
+	format.UpdatedBy = updatedBy
+
+	if err := s.repo.SaveFormat(ctx, format); err != nil {
+		return nil, err
+	}
+
+	sequence, err := s.repo.GetSequence(ctx, festivalID, docType, now.Year())
+	if err != nil {
+		return nil, err
+	}
+	var lastValue int64
+	if sequence != nil {
+		lastValue = sequence.LastValue
+	}
+
+	response := format.ToResponse(now.Year(), lastValue)
+	return &response, nil
+}
+
+// ListNumbers lists the numbers allocated for a document type in a year,
+// the current year of the festival by default
+func (s *Service) ListNumbers(ctx context.Context, festivalID uuid.UUID, docType DocumentType, query ListNumbersQuery) ([]AllocatedNumber, int64, error) {
+	if !docType.IsValid() {
+		return nil, 0, ErrInvalidDocumentType
+	}
+	if query.Year == 0 {
+		now, err := s.festivalNow(ctx, festivalID)
+		if err != nil {
+			return nil, 0, err
+		}
+		query.Year = now.Year()
+	}
+	return s.repo.ListNumbers(ctx, festivalID, docType, query)
+}
+
+// Audit checks that every value of a sequence up to its counter has a recorded number
+func (s *Service) Audit(ctx context.Context, festivalID uuid.UUID, docType DocumentType, year int) (*AuditReport, error) {
+	if !docType.IsValid() {
+		return nil, ErrInvalidDocumentType
+	}
+
+	now, err := s.festivalNow(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if year == 0 {
+		year = now.Year()
+	}
+	if year < 2000 || year > now.Year() {
+		return nil, ErrInvalidYear
+	}
+
+	report := &AuditReport{
+		DocumentType: docType,
+		Year:         year,
+		Missing:      []int64{},
+	}
+
+	sequence, err := s.repo.GetSequence(ctx, festivalID, docType, year)
+	if err != nil {
+		return nil, err
+	}
+	if sequence != nil {
+		report.LastValue = sequence.LastValue
+	}
+
+	report.Allocated, err = s.repo.CountNumbers(ctx, festivalID, docType, year)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Allocated != report.LastValue {
+		report.Missing, err = s.repo.ListMissingSequences(ctx, festivalID, docType, year, report.LastValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.Gapless = report.Allocated == report.LastValue && len(report.Missing) == 0
+	return report, nil
+}
+
+// festivalNow returns the current time in the festival's timezone, in which
+// numbering years start and end
+func (s *Service) festivalNow(ctx context.Context, festivalID uuid.UUID) (time.Time, error) {
+	timezone, err := s.repo.GetFestivalTimezone(ctx, festivalID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if timezone == nil {
+		return time.Time{}, ErrFestivalNotFound
+	}
+	return time.Now().In(tz.Load(*timezone)), nil
+}
+
+// getFormat returns the saved format of a document type, or its default
+func (s *Service) getFormat(ctx context.Context, festivalID uuid.UUID, docType DocumentType) (*Format, error) {
+	format, err := s.repo.GetFormat(ctx, festivalID, docType)
+	if err != nil {
+		return nil, err
+	}
+	if format == nil {
+		defaultFormat := DefaultFormat(festivalID, docType)
+		return &defaultFormat, nil
+	}
+	return format, nil
+}
@@ -0,0 +1,520 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/auth"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// principalKey is where the authenticated client of a request is kept in the gin context
+const principalKey = "oauth_principal"
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped client registration routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	clients := r.Group("/oauth-clients")
+	{
+		clients.GET("", h.ListClients)
+		clients.POST("", h.CreateClient)
+		clients.GET("/scopes", h.ListScopes)
+		clients.GET("/usage", h.UsageDashboard)
+		clients.GET("/:clientId", h.GetClient)
+		clients.PATCH("/:clientId", h.UpdateClient)
+		clients.DELETE("/:clientId", h.DeleteClient)
+		clients.POST("/:clientId/secret", h.RotateSecret)
+		clients.POST("/:clientId/revoke", h.RevokeClient)
+		clients.GET("/:clientId/usage", h.GetUsage)
+	}
+}
+
+// RegisterTokenRoutes registers the token endpoint, authenticated with the client
+// credentials
+func (h *Handler) RegisterTokenRoutes(r *gin.RouterGroup) {
+	r.POST("/token", h.Token)
+}
+
+// ListClients lists the OAuth clients of the festival
+// @Summary List OAuth clients
+// @Description List the machine clients registered for the festival, with their scopes and rate limits
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Client} "Clients"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients [get]
+func (h *Handler) ListClients(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	clients, err := h.service.ListClients(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, clients)
+}
+
+// CreateClient registers an OAuth client
+// @Summary Register OAuth client
+// @Description Register a machine client getting access tokens with the client credentials grant. Scopes are RBAC permissions, "resource.*" grants every action of a resource. The client secret is only returned once.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateClientRequest true "Client"
+// @Success 201 {object} response.Response{data=ClientWithSecret} "Client registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid scopes or rate limits"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients [post]
+func (h *Handler) CreateClient(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		createdBy = &userID
+	}
+
+	client, err := h.service.RegisterClient(c.Request.Context(), festivalID, createdBy, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, client)
+}
+
+// ListScopes lists the scopes OAuth clients can be granted
+// @Summary List OAuth scopes
+// @Description List the RBAC permissions machine clients can be granted as scopes
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]ScopeInfo} "Scopes"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/scopes [get]
+func (h *Handler) ListScopes(c *gin.Context) {
+	response.OK(c, h.service.GrantableScopes())
+}
+
+// GetClient returns an OAuth client
+// @Summary Get OAuth client
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param clientId path string true "Client ID" format(uuid)
+// @Success 200 {object} response.Response{data=Client} "Client"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/{clientId} [get]
+func (h *Handler) GetClient(c *gin.Context) {
+	festivalID, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+
+	client, err := h.service.GetClient(c.Request.Context(), festivalID, clientID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, client)
+}
+
+// UpdateClient updates an OAuth client
+// @Summary Update OAuth client
+// @Description Update the name, scopes or rate limits of a client. Changes apply to the access tokens already issued.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param clientId path string true "Client ID" format(uuid)
+// @Param request body UpdateClientRequest true "Client changes"
+// @Success 200 {object} response.Response{data=Client} "Client updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid scopes or rate limits"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/{clientId} [patch]
+func (h *Handler) UpdateClient(c *gin.Context) {
+	festivalID, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	client, err := h.service.UpdateClient(c.Request.Context(), festivalID, clientID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, client)
+}
+
+// DeleteClient deletes an OAuth client
+// @Summary Delete OAuth client
+// @Description Delete a client with its usage. Its access tokens are rejected from now on.
+// @Tags oauth
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param clientId path string true "Client ID" format(uuid)
+// @Success 204 "Client deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/{clientId} [delete]
+func (h *Handler) DeleteClient(c *gin.Context) {
+	festivalID, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteClient(c.Request.Context(), festivalID, clientID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// RotateSecret replaces the secret of an OAuth client
+// @Summary Rotate OAuth client secret
+// @Description Replace the client secret. Access tokens issued with the previous secret are rejected from now on. The new secret is only returned once.
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param clientId path string true "Client ID" format(uuid)
+// @Success 200 {object} response.Response{data=ClientWithSecret} "Secret rotated"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Failure 409 {object} response.ErrorResponse "Client revoked"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/{clientId}/secret [post]
+func (h *Handler) RotateSecret(c *gin.Context) {
+	festivalID, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+
+	client, err := h.service.RotateSecret(c.Request.Context(), festivalID, clientID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, client)
+}
+
+// RevokeClient revokes an OAuth client
+// @Summary Revoke OAuth client
+// @Description Revoke a client for good. It gets no more tokens and the tokens it was issued are rejected.
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param clientId path string true "Client ID" format(uuid)
+// @Success 200 {object} response.Response{data=Client} "Client revoked"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/{clientId}/revoke [post]
+func (h *Handler) RevokeClient(c *gin.Context) {
+	festivalID, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+
+	client, err := h.service.RevokeClient(c.Request.Context(), festivalID, clientID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, client)
+}
+
+// GetUsage reports the metered usage of an OAuth client
+// @Summary Get OAuth client usage
+// @Description Hourly requests, errors, throttled requests and issued tokens of a client, the last 24 hours by default and 31 days at most
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param clientId path string true "Client ID" format(uuid)
+// @Param from query string false "Start of the period (RFC 3339)"
+// @Param to query string false "End of the period (RFC 3339)"
+// @Success 200 {object} response.Response{data=UsageReport} "Usage"
+// @Failure 400 {object} response.ErrorResponse "Invalid period"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/{clientId}/usage [get]
+func (h *Handler) GetUsage(c *gin.Context) {
+	festivalID, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+
+	var query UsageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	report, err := h.service.GetUsage(c.Request.Context(), festivalID, clientID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// UsageDashboard reports the usage of the OAuth clients of the festival over a month
+// @Summary Get OAuth client usage dashboard
+// @Description Requests of each client over a UTC month, by endpoint class and day, against its monthly quota. Clients past the warning threshold of their quota are listed in the warnings.
+// @Tags oauth
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param month query string false "Month (YYYY-MM), the current one by default"
+// @Success 200 {object} response.Response{data=UsageDashboard} "Usage dashboard"
+// @Failure 400 {object} response.ErrorResponse "Invalid month"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/oauth-clients/usage [get]
+func (h *Handler) UsageDashboard(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query UsageDashboardQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	dashboard, err := h.service.UsageDashboard(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, dashboard)
+}
+
+// Token issues an access token with the client credentials grant
+// @Summary Get an access token
+// @Description OAuth 2.0 client credentials grant (RFC 6749 section 4.4). Authenticate with HTTP Basic or the client_id and client_secret form fields. Errors follow RFC 6749 section 5.2.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "client_credentials"
+// @Param scope formData string false "Space separated scopes, every granted scope when empty"
+// @Success 200 {object} TokenResponse "Access token"
+// @Failure 400 {object} TokenError "Invalid request or scope"
+// @Failure 401 {object} TokenError "Invalid client"
+// @Router /oauth/token [post]
+func (h *Handler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, TokenError{Error: "invalid_request", Description: "Invalid token request"})
+		return
+	}
+
+	basic := false
+	if clientID, secret, ok := c.Request.BasicAuth(); ok {
+		if req.ClientID != "" || req.ClientSecret != "" {
+			c.JSON(http.StatusBadRequest, TokenError{Error: "invalid_request", Description: "Use either HTTP Basic or the form fields to authenticate"})
+			return
+		}
+		req.ClientID, req.ClientSecret, basic = clientID, secret, true
+	}
+	if req.GrantType == "" || req.ClientID == "" || req.ClientSecret == "" {
+		c.JSON(http.StatusBadRequest, TokenError{Error: "invalid_request", Description: "grant_type and the client credentials are required"})
+		return
+	}
+
+	token, err := h.service.IssueToken(c.Request.Context(), req)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, token)
+	case errors.Is(err, ErrUnsupportedGrant):
+		c.JSON(http.StatusBadRequest, TokenError{Error: "unsupported_grant_type", Description: err.Error()})
+	case errors.Is(err, ErrInvalidClient):
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		c.JSON(http.StatusUnauthorized, TokenError{Error: "invalid_client", Description: err.Error()})
+	case errors.Is(err, ErrInvalidScope), errors.Is(err, ErrScopeNotGranted):
+		c.JSON(http.StatusBadRequest, TokenError{Error: "invalid_scope", Description: err.Error()})
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+// Authenticate authenticates the requests of machine clients with their access token,
+// applies their rate limits and monthly quota and meters them by endpoint class. The festival of the token is set as
+// festival_id, and must match the :id path parameter when there is one.
+func (h *Handler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		principal, err := h.service.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				response.Unauthorized(c, err.Error())
+			} else {
+				response.InternalError(c, err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		client := principal.Client
+		if id := c.Param("id"); id != "" && id != client.FestivalID.String() {
+			response.Forbidden(c, ErrFestivalMismatch.Error())
+			c.Abort()
+			return
+		}
+
+		class := classifyEndpoint(c.FullPath())
+		if allowed, retryAfter := h.service.Allow(c.Request.Context(), client); !allowed {
+			h.service.Meter(c.Request.Context(), client, UsageEvent{Class: class, Throttled: true})
+			response.TooManyRequests(c, int(math.Ceil(retryAfter.Seconds())))
+			c.Abort()
+			return
+		}
+
+		if quota := h.service.CountQuota(c.Request.Context(), client); quota != nil {
+			c.Header("X-Quota-Limit", strconv.FormatInt(quota.Limit, 10))
+			c.Header("X-Quota-Remaining", strconv.FormatInt(max(quota.Limit-quota.Used, 0), 10))
+			if quota.Exceeded {
+				h.service.Meter(c.Request.Context(), client, UsageEvent{Class: class, QuotaExceeded: true})
+				retryAfter := int(math.Ceil(time.Until(quota.ResetsAt).Seconds()))
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				response.SendError(c, response.NewStandardError(http.StatusTooManyRequests, "QUOTA_EXCEEDED", ErrQuotaExceeded.Error(), map[string]interface{}{
+					"quota":               quota.Limit,
+					"resets_at":           quota.ResetsAt,
+					"retry_after_seconds": retryAfter,
+				}))
+				c.Abort()
+				return
+			}
+		}
+
+		permissions := make([]string, len(principal.Scopes))
+		for i, scope := range principal.Scopes {
+			permissions[i] = string(scope)
+		}
+		c.Set(principalKey, principal)
+		c.Set("festival_id", client.FestivalID.String())
+		c.Set("permissions", permissions)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		go h.service.Meter(context.Background(), client, UsageEvent{Class: class, Request: true, Error: status >= http.StatusBadRequest})
+	}
+}
+
+// RequireScope allows the requests of machine clients whose access token carries scope
+func RequireScope(scope auth.PermissionString) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil || !principal.HasScope(scope) {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+string(scope)+`"`)
+			response.Forbidden(c, ErrInsufficientScope.Error()+": "+string(scope))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal returns the machine client authenticated for the request, if any
+func GetPrincipal(c *gin.Context) *Principal {
+	if value, ok := c.Get(principalKey); ok {
+		if principal, ok := value.(*Principal); ok {
+			return principal
+		}
+	}
+	return nil
+}
+
+func clientParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	clientID, err := uuid.Parse(c.Param("clientId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid client ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, clientID, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrClientNotFound):
+		response.NotFound(c, "OAuth client not found")
+	case errors.Is(err, ErrClientRevoked):
+		response.Conflict(c, "CLIENT_REVOKED", err.Error())
+	case errors.Is(err, ErrInvalidScope):
+		response.BadRequest(c, "INVALID_SCOPE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRateLimit):
+		response.BadRequest(c, "INVALID_RATE_LIMIT", err.Error(), nil)
+	case errors.Is(err, ErrInvalidUsagePeriod):
+		response.BadRequest(c, "INVALID_PERIOD", err.Error(), nil)
+	case errors.Is(err, ErrInvalidQuota):
+		response.BadRequest(c, "INVALID_QUOTA", err.Error(), nil)
+	case errors.Is(err, ErrInvalidMonth):
+		response.BadRequest(c, "INVALID_MONTH", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
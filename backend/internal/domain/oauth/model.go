@@ -0,0 +1,314 @@
+package oauth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/auth"
+)
+
+// OAuth client errors
+var (
+	ErrClientNotFound     = errors.New("oauth client not found")
+	ErrClientRevoked      = errors.New("oauth client is revoked")
+	ErrInvalidClient      = errors.New("invalid client credentials")
+	ErrInvalidScope       = errors.New("unknown or not grantable scope")
+	ErrScopeNotGranted    = errors.New("scope not granted to the client")
+	ErrUnsupportedGrant   = errors.New("only the client_credentials grant is supported")
+	ErrInvalidToken       = errors.New("invalid or expired access token")
+	ErrInsufficientScope  = errors.New("access token lacks the required scope")
+	ErrFestivalMismatch   = errors.New("access token was issued for another festival")
+	ErrInvalidRateLimit   = errors.New("rate limits must be positive, with the daily limit at least the per-minute one")
+	ErrInvalidUsagePeriod = errors.New("usage period must end after it starts and span at most 31 days")
+	ErrInvalidQuota       = errors.New("monthly quota must be positive, with a warning threshold between 1 and 100 percent")
+	ErrInvalidMonth       = errors.New("month must be formatted as YYYY-MM")
+	ErrQuotaExceeded      = errors.New("monthly request quota of the client exceeded")
+)
+
+// Tokens and rate limits
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	TokenType                  = "Bearer"
+	TokenTTL                   = time.Hour
+	TokenIssuer                = "festivals-oauth"
+	ClientIDPrefix             = "cli_"
+	ClientSecretPrefix         = "cs_"
+	DefaultRequestsPerMinute   = 120
+	DefaultRequestsPerDay      = 50000
+	MaxUsagePeriod             = 31 * 24 * time.Hour
+	DefaultQuotaWarningPercent = 80
+)
+
+// EndpointClass groups the integration endpoints a client calls, for metering
+type EndpointClass string
+
+const (
+	EndpointClassExports EndpointClass = "exports"
+	EndpointClassOther   EndpointClass = "other" // Endpoints of no known class
+)
+
+// endpointClasses are the classes named after the first path segment under the
+// festival of the integration routes
+var endpointClasses = map[string]EndpointClass{
+	"exports": EndpointClassExports,
+}
+
+// QuotaStatus is where a client stands against its monthly quota
+type QuotaStatus string
+
+const (
+	QuotaStatusNone     QuotaStatus = "NONE" // The client has no quota
+	QuotaStatusOK       QuotaStatus = "OK"
+	QuotaStatusWarning  QuotaStatus = "WARNING" // The warning threshold is reached
+	QuotaStatusExceeded QuotaStatus = "EXCEEDED"
+)
+
+// nonGrantableResources are RBAC resources machine clients never get, whatever they ask for
+var nonGrantableResources = map[string]bool{
+	"api":   true, // Clients cannot register other clients
+	"roles": true,
+	"users": true,
+}
+
+// ClientStatus is whether a client can still get tokens
+type ClientStatus string
+
+const (
+	ClientStatusActive  ClientStatus = "ACTIVE"
+	ClientStatusRevoked ClientStatus = "REVOKED" // Tokens already issued are rejected too
+)
+
+// Client is a machine client of a festival, authenticated with the client credentials grant
+type Client struct {
+	ID                  uuid.UUID               `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID          uuid.UUID               `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ClientID            string                  `json:"clientId" gorm:"not null;uniqueIndex"`
+	Name                string                  `json:"name" gorm:"not null"`
+	Description         string                  `json:"description,omitempty"`
+	SecretHash          string                  `json:"-" gorm:"not null"`
+	SecretPrefix        string                  `json:"secretPrefix"` // Identifies the secret without revealing it
+	Scopes              []auth.PermissionString `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`
+	RequestsPerMinute   int                     `json:"requestsPerMinute" gorm:"not null"`
+	RequestsPerDay      int                     `json:"requestsPerDay" gorm:"not null"`
+	MonthlyQuota        *int64                  `json:"monthlyQuota,omitempty"` // Requests per UTC calendar month of the contract
+	QuotaWarningPercent int                     `json:"quotaWarningPercent" gorm:"not null;default:80"`
+	EnforceQuota        bool                    `json:"enforceQuota" gorm:"not null;default:false"` // Refuse requests over the quota instead of only warning
+	Status              ClientStatus            `json:"status" gorm:"not null;default:'ACTIVE'"`
+	SecretRotatedAt     time.Time               `json:"secretRotatedAt"` // Tokens issued before are rejected
+	LastUsedAt          *time.Time              `json:"lastUsedAt,omitempty"`
+	CreatedBy           *uuid.UUID              `json:"createdBy,omitempty" gorm:"type:uuid"`
+	RevokedAt           *time.Time              `json:"revokedAt,omitempty"`
+	CreatedAt           time.Time               `json:"createdAt"`
+	UpdatedAt           time.Time               `json:"updatedAt"`
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+// HasScope reports whether the client was granted scope
+func (c *Client) HasScope(scope auth.PermissionString) bool {
+	return auth.HasPermissionInSet(scope, c.Scopes)
+}
+
+// ClientUsage meters the requests of a client over an hour
+type ClientUsage struct {
+	ClientID      uuid.UUID `json:"-" gorm:"type:uuid;primaryKey"`
+	FestivalID    uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	Hour          time.Time `json:"hour" gorm:"primaryKey"`
+	Requests      int64     `json:"requests" gorm:"not null;default:0"`
+	Errors        int64     `json:"errors" gorm:"not null;default:0"`        // Responses with a 4xx or 5xx status, throttled ones excluded
+	Throttled     int64     `json:"throttled" gorm:"not null;default:0"`     // Requests refused by the rate limits
+	QuotaExceeded int64     `json:"quotaExceeded" gorm:"not null;default:0"` // Requests refused by the enforced quota
+	TokensIssued  int64     `json:"tokensIssued" gorm:"not null;default:0"`
+}
+
+func (ClientUsage) TableName() string {
+	return "oauth_client_usage"
+}
+
+// DailyUsage meters the requests of a client to a class of endpoints over a UTC day.
+// Quotas are counted on these aggregates.
+type DailyUsage struct {
+	ClientID      uuid.UUID     `json:"clientId" gorm:"type:uuid;primaryKey"`
+	FestivalID    uuid.UUID     `json:"-" gorm:"type:uuid;not null"`
+	Day           time.Time     `json:"day" gorm:"type:date;primaryKey"`
+	EndpointClass EndpointClass `json:"endpointClass" gorm:"primaryKey"`
+	Requests      int64         `json:"requests" gorm:"not null;default:0"`
+	Errors        int64         `json:"errors" gorm:"not null;default:0"`
+	Throttled     int64         `json:"throttled" gorm:"not null;default:0"`
+	QuotaExceeded int64         `json:"quotaExceeded" gorm:"not null;default:0"`
+}
+
+func (DailyUsage) TableName() string {
+	return "oauth_client_daily_usage"
+}
+
+// UsageEvent is what a metered call adds to the hour of a client, and to its day when
+// it is a request to an endpoint
+type UsageEvent struct {
+	Class         EndpointClass // Class of the endpoint called, none for the token endpoint
+	Request       bool
+	Error         bool
+	Throttled     bool
+	QuotaExceeded bool
+	TokenIssued   bool
+}
+
+// CreateClientRequest represents the request to register a client
+type CreateClientRequest struct {
+	Name                string   `json:"name" binding:"required,max=100"`
+	Description         string   `json:"description" binding:"max=500"`
+	Scopes              []string `json:"scopes" binding:"required,min=1"` // Permission strings, "resource.*" for every action
+	RequestsPerMinute   int      `json:"requestsPerMinute"`
+	RequestsPerDay      int      `json:"requestsPerDay"`
+	MonthlyQuota        *int64   `json:"monthlyQuota,omitempty"`
+	QuotaWarningPercent int      `json:"quotaWarningPercent"` // 80 by default
+	EnforceQuota        bool     `json:"enforceQuota"`
+}
+
+// UpdateClientRequest represents the request to update a client
+type UpdateClientRequest struct {
+	Name                *string  `json:"name,omitempty" binding:"omitempty,max=100"`
+	Description         *string  `json:"description,omitempty" binding:"omitempty,max=500"`
+	Scopes              []string `json:"scopes,omitempty"`
+	RequestsPerMinute   *int     `json:"requestsPerMinute,omitempty"`
+	RequestsPerDay      *int     `json:"requestsPerDay,omitempty"`
+	MonthlyQuota        *int64   `json:"monthlyQuota,omitempty"` // 0 removes the quota
+	QuotaWarningPercent *int     `json:"quotaWarningPercent,omitempty"`
+	EnforceQuota        *bool    `json:"enforceQuota,omitempty"`
+}
+
+// ClientWithSecret includes the client secret, only returned on registration and rotation
+type ClientWithSecret struct {
+	Client
+	ClientSecret string `json:"clientSecret"`
+}
+
+// UsageQuery selects the hours of a usage report, the last 24 hours by default
+type UsageQuery struct {
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// UsageReport is the metered usage of a client over a period
+type UsageReport struct {
+	ClientID          uuid.UUID     `json:"clientId"`
+	From              time.Time     `json:"from"`
+	To                time.Time     `json:"to"`
+	Requests          int64         `json:"requests"`
+	Errors            int64         `json:"errors"`
+	Throttled         int64         `json:"throttled"`
+	TokensIssued      int64         `json:"tokensIssued"`
+	RequestsToday     int64         `json:"requestsToday"` // Counted against requestsPerDay, UTC day
+	RequestsPerMinute int           `json:"requestsPerMinute"`
+	RequestsPerDay    int           `json:"requestsPerDay"`
+	Hours             []ClientUsage `json:"hours"`
+}
+
+// UsageDashboardQuery selects the month of the usage dashboard, the current one by
+// default
+type UsageDashboardQuery struct {
+	Month string `form:"month"` // YYYY-MM
+}
+
+// UsageDashboard is the usage of the clients of a festival over a month, against their
+// quotas
+type UsageDashboard struct {
+	FestivalID uuid.UUID      `json:"festivalId"`
+	Month      string         `json:"month"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Requests   int64          `json:"requests"`
+	Clients    []ClientQuota  `json:"clients"`
+	Warnings   []QuotaWarning `json:"warnings"`
+}
+
+// ClientQuota is the usage of a client over the month of a dashboard
+type ClientQuota struct {
+	ClientID         uuid.UUID       `json:"clientId"`
+	Name             string          `json:"name"`
+	Status           ClientStatus    `json:"status"`
+	Requests         int64           `json:"requests"`
+	Errors           int64           `json:"errors"`
+	Throttled        int64           `json:"throttled"`
+	QuotaExceeded    int64           `json:"quotaExceeded"`
+	MonthlyQuota     *int64          `json:"monthlyQuota,omitempty"`
+	QuotaUsedPercent float64         `json:"quotaUsedPercent"`
+	QuotaStatus      QuotaStatus     `json:"quotaStatus"`
+	EnforceQuota     bool            `json:"enforceQuota"`
+	ByClass          []ClassUsage    `json:"byClass"`
+	Days             []DailyRequests `json:"days"`
+}
+
+// ClassUsage is the usage of a class of endpoints over the month of a dashboard
+type ClassUsage struct {
+	EndpointClass EndpointClass `json:"endpointClass"`
+	Requests      int64         `json:"requests"`
+	Errors        int64         `json:"errors"`
+}
+
+// DailyRequests is the number of requests of a client on a day
+type DailyRequests struct {
+	Day      string `json:"day"` // YYYY-MM-DD
+	Requests int64  `json:"requests"`
+}
+
+// QuotaWarning flags a client at or over the warning threshold of its quota
+type QuotaWarning struct {
+	ClientID    uuid.UUID   `json:"clientId"`
+	Name        string      `json:"name"`
+	QuotaStatus QuotaStatus `json:"quotaStatus"`
+	Message     string      `json:"message"`
+}
+
+// QuotaCheck is where a client stands against its monthly quota once a request is
+// counted
+type QuotaCheck struct {
+	Limit    int64
+	Used     int64
+	ResetsAt time.Time // Start of the next UTC month
+	Exceeded bool      // Over the quota while it is enforced
+}
+
+// TokenRequest is the RFC 6749 token request, form encoded. The credentials may also
+// be sent with HTTP Basic authentication.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"` // Space separated, every granted scope when empty
+}
+
+// TokenResponse is the RFC 6749 access token response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// TokenError is the RFC 6749 error response of the token endpoint
+type TokenError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// ScopeInfo describes a scope clients can be granted
+type ScopeInfo struct {
+	Scope    auth.PermissionString `json:"scope"`
+	Resource string                `json:"resource"`
+	Action   string                `json:"action"`
+}
+
+// Principal is the authenticated client of an API request
+type Principal struct {
+	Client *Client
+	Scopes []auth.PermissionString // Scopes of the access token, a subset of the client's
+}
+
+// HasScope reports whether the access token carries scope
+func (p *Principal) HasScope(scope auth.PermissionString) bool {
+	return auth.HasPermissionInSet(scope, p.Scopes)
+}
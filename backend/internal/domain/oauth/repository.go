@@ -0,0 +1,231 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreateClient(ctx context.Context, client *Client) error
+	GetClient(ctx context.Context, festivalID, id uuid.UUID) (*Client, error)
+	GetClientByID(ctx context.Context, id uuid.UUID) (*Client, error)
+	GetClientByClientID(ctx context.Context, clientID string) (*Client, error)
+	ListClients(ctx context.Context, festivalID uuid.UUID) ([]Client, error)
+	UpdateClient(ctx context.Context, client *Client) error
+	DeleteClient(ctx context.Context, id uuid.UUID) error
+	TouchClient(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	RecordUsage(ctx context.Context, client *Client, hour time.Time, event UsageEvent) error
+	ListUsage(ctx context.Context, clientID uuid.UUID, from, to time.Time) ([]ClientUsage, error)
+	ListDailyUsage(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]DailyUsage, error)
+	CountRequests(ctx context.Context, clientID uuid.UUID, from, to time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateClient(ctx context.Context, client *Client) error {
+	if err := r.db.WithContext(ctx).Create(client).Error; err != nil {
+		return fmt.Errorf("failed to create oauth client: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetClient(ctx context.Context, festivalID, id uuid.UUID) (*Client, error) {
+	var client Client
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	return &client, nil
+}
+
+func (r *repository) GetClientByID(ctx context.Context, id uuid.UUID) (*Client, error) {
+	var client Client
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	return &client, nil
+}
+
+func (r *repository) GetClientByClientID(ctx context.Context, clientID string) (*Client, error) {
+	var client Client
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	return &client, nil
+}
+
+func (r *repository) ListClients(ctx context.Context, festivalID uuid.UUID) ([]Client, error) {
+	var clients []Client
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ?", festivalID).
+		Order("created_at DESC").
+		Find(&clients).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth clients: %w", err)
+	}
+	return clients, nil
+}
+
+func (r *repository) UpdateClient(ctx context.Context, client *Client) error {
+	if err := r.db.WithContext(ctx).Save(client).Error; err != nil {
+		return fmt.Errorf("failed to update oauth client: %w", err)
+	}
+	return nil
+}
+
+// DeleteClient deletes a client with its usage
+func (r *repository) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", id).Delete(&ClientUsage{}).Error; err != nil {
+			return fmt.Errorf("failed to delete oauth client usage: %w", err)
+		}
+		if err := tx.Where("client_id = ?", id).Delete(&DailyUsage{}).Error; err != nil {
+			return fmt.Errorf("failed to delete oauth client daily usage: %w", err)
+		}
+		if err := tx.Delete(&Client{}, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to delete oauth client: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) TouchClient(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Client{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to update oauth client: %w", err)
+	}
+	return nil
+}
+
+// RecordUsage adds an event to the usage of the client for the hour, creating the
+// hour on its first event. Requests to an endpoint are added to the day of their class
+// as well.
+func (r *repository) RecordUsage(ctx context.Context, client *Client, hour time.Time, event UsageEvent) error {
+	usage := ClientUsage{
+		ClientID:      client.ID,
+		FestivalID:    client.FestivalID,
+		Hour:          hour,
+		Requests:      count(event.Request),
+		Errors:        count(event.Error),
+		Throttled:     count(event.Throttled),
+		QuotaExceeded: count(event.QuotaExceeded),
+		TokensIssued:  count(event.TokenIssued),
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "client_id"}, {Name: "hour"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests":       gorm.Expr("oauth_client_usage.requests + ?", usage.Requests),
+				"errors":         gorm.Expr("oauth_client_usage.errors + ?", usage.Errors),
+				"throttled":      gorm.Expr("oauth_client_usage.throttled + ?", usage.Throttled),
+				"quota_exceeded": gorm.Expr("oauth_client_usage.quota_exceeded + ?", usage.QuotaExceeded),
+				"tokens_issued":  gorm.Expr("oauth_client_usage.tokens_issued + ?", usage.TokensIssued),
+			}),
+		}).Create(&usage).Error
+		if err != nil {
+			return fmt.Errorf("failed to record oauth client usage: %w", err)
+		}
+		if event.Class == "" {
+			return nil
+		}
+
+		day := DailyUsage{
+			ClientID:      client.ID,
+			FestivalID:    client.FestivalID,
+			Day:           hour.Truncate(24 * time.Hour),
+			EndpointClass: event.Class,
+			Requests:      usage.Requests,
+			Errors:        usage.Errors,
+			Throttled:     usage.Throttled,
+			QuotaExceeded: usage.QuotaExceeded,
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "client_id"}, {Name: "day"}, {Name: "endpoint_class"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests":       gorm.Expr("oauth_client_daily_usage.requests + ?", day.Requests),
+				"errors":         gorm.Expr("oauth_client_daily_usage.errors + ?", day.Errors),
+				"throttled":      gorm.Expr("oauth_client_daily_usage.throttled + ?", day.Throttled),
+				"quota_exceeded": gorm.Expr("oauth_client_daily_usage.quota_exceeded + ?", day.QuotaExceeded),
+			}),
+		}).Create(&day).Error
+		if err != nil {
+			return fmt.Errorf("failed to record oauth client daily usage: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListUsage lists the metered hours of a client from from (inclusive) to to (exclusive)
+func (r *repository) ListUsage(ctx context.Context, clientID uuid.UUID, from, to time.Time) ([]ClientUsage, error) {
+	var usage []ClientUsage
+	err := r.db.WithContext(ctx).
+		Where("client_id = ? AND hour >= ? AND hour < ?", clientID, from, to).
+		Order("hour").
+		Find(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth client usage: %w", err)
+	}
+	return usage, nil
+}
+
+// ListDailyUsage lists the metered days of the clients of a festival from from
+// (inclusive) to to (exclusive)
+func (r *repository) ListDailyUsage(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]DailyUsage, error) {
+	var usage []DailyUsage
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND day >= ? AND day < ?", festivalID, from, to).
+		Order("client_id, day, endpoint_class").
+		Find(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth client daily usage: %w", err)
+	}
+	return usage, nil
+}
+
+// CountRequests counts the metered requests of a client from from (inclusive) to to
+// (exclusive), whole days
+func (r *repository) CountRequests(ctx context.Context, clientID uuid.UUID, from, to time.Time) (int64, error) {
+	var requests int64
+	err := r.db.WithContext(ctx).Model(&DailyUsage{}).
+		Select("COALESCE(SUM(requests), 0)").
+		Where("client_id = ? AND day >= ? AND day < ?", clientID, from, to).
+		Scan(&requests).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count oauth client requests: %w", err)
+	}
+	return requests, nil
+}
+
+func count(happened bool) int64 {
+	if happened {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package oauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateClient(ctx context.Context, client *Client) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetClient(ctx context.Context, festivalID, id uuid.UUID) (*Client, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Client), args.Error(1)
+}
+
+func (m *MockRepository) GetClientByID(ctx context.Context, id uuid.UUID) (*Client, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Client), args.Error(1)
+}
+
+func (m *MockRepository) GetClientByClientID(ctx context.Context, clientID string) (*Client, error) {
+	args := m.Called(ctx, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Client), args.Error(1)
+}
+
+func (m *MockRepository) ListClients(ctx context.Context, festivalID uuid.UUID) ([]Client, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Client), args.Error(1)
+}
+
+func (m *MockRepository) UpdateClient(ctx context.Context, client *Client) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchClient(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordUsage(ctx context.Context, client *Client, hour time.Time, event UsageEvent) error {
+	args := m.Called(ctx, client, hour, event)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListUsage(ctx context.Context, clientID uuid.UUID, from, to time.Time) ([]ClientUsage, error) {
+	args := m.Called(ctx, clientID, from, to)
+	return args.Get(0).([]ClientUsage), args.Error(1)
+}
+
+func (m *MockRepository) ListDailyUsage(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]DailyUsage, error) {
+	args := m.Called(ctx, festivalID, from, to)
+	return args.Get(0).([]DailyUsage), args.Error(1)
+}
+
+func (m *MockRepository) CountRequests(ctx context.Context, clientID uuid.UUID, from, to time.Time) (int64, error) {
+	args := m.Called(ctx, clientID, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,761 @@
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/auth"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// tokenKeyLabel separates the access token signing key from the other values signed
+// with the keyring secrets
+const tokenKeyLabel = "oauth-access-token"
+
+// tokenClaims are the claims of a client access token
+type tokenClaims struct {
+	FestivalID string `json:"festival_id"`
+	ClientID   string `json:"client_id"`
+	Scope      string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// Service manages the machine clients of the festivals and the access tokens they get
+// with the client credentials grant
+type Service struct {
+	repo    Repository
+	keyring *security.Keyring
+	redis   *redis.Client
+	now     func() time.Time
+}
+
+// NewService creates an OAuth client service. Access tokens are signed with a key
+// derived from the current keyring secret, so they follow its rotations. Rate limits
+// are counted in Redis and not enforced without a client.
+func NewService(repo Repository, keyring *security.Keyring, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:    repo,
+		keyring: keyring,
+		redis:   redisClient,
+		now:     time.Now,
+	}
+}
+
+// RegisterClient registers a machine client of a festival and returns its secret,
+// which is only shown once
+func (s *Service) RegisterClient(ctx context.Context, festivalID uuid.UUID, createdBy *uuid.UUID, req CreateClientRequest) (*ClientWithSecret, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RequestsPerMinute == 0 {
+		req.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if req.RequestsPerDay == 0 {
+		req.RequestsPerDay = DefaultRequestsPerDay
+	}
+	if err := validateRateLimits(req.RequestsPerMinute, req.RequestsPerDay); err != nil {
+		return nil, err
+	}
+	if req.QuotaWarningPercent == 0 {
+		req.QuotaWarningPercent = DefaultQuotaWarningPercent
+	}
+	if err := validateQuota(req.MonthlyQuota, req.QuotaWarningPercent); err != nil {
+		return nil, err
+	}
+
+	clientID, err := generateClientID()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	client := &Client{
+		ID:                  uuid.New(),
+		FestivalID:          festivalID,
+		ClientID:            clientID,
+		Name:                strings.TrimSpace(req.Name),
+		Description:         req.Description,
+		SecretHash:          hashSecret(secret),
+		SecretPrefix:        secret[:len(ClientSecretPrefix)+6],
+		Scopes:              scopes,
+		RequestsPerMinute:   req.RequestsPerMinute,
+		RequestsPerDay:      req.RequestsPerDay,
+		MonthlyQuota:        req.MonthlyQuota,
+		QuotaWarningPercent: req.QuotaWarningPercent,
+		EnforceQuota:        req.EnforceQuota,
+		Status:              ClientStatusActive,
+		SecretRotatedAt:     now,
+		CreatedBy:           createdBy,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return nil, err
+	}
+
+	return &ClientWithSecret{Client: *client, ClientSecret: secret}, nil
+}
+
+// ListClients lists the clients of a festival
+func (s *Service) ListClients(ctx context.Context, festivalID uuid.UUID) ([]Client, error) {
+	return s.repo.ListClients(ctx, festivalID)
+}
+
+// GetClient returns a client of a festival
+func (s *Service) GetClient(ctx context.Context, festivalID, id uuid.UUID) (*Client, error) {
+	client, err := s.repo.GetClient(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrClientNotFound
+	}
+	return client, nil
+}
+
+// UpdateClient updates a client. Scope, rate limit and quota changes apply to the tokens
+// already issued.
+func (s *Service) UpdateClient(ctx context.Context, festivalID, id uuid.UUID, req UpdateClientRequest) (*Client, error) {
+	client, err := s.GetClient(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		client.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		client.Description = *req.Description
+	}
+	if req.Scopes != nil {
+		scopes, err := normalizeScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		client.Scopes = scopes
+	}
+	if req.RequestsPerMinute != nil {
+		client.RequestsPerMinute = *req.RequestsPerMinute
+	}
+	if req.RequestsPerDay != nil {
+		client.RequestsPerDay = *req.RequestsPerDay
+	}
+	if err := validateRateLimits(client.RequestsPerMinute, client.RequestsPerDay); err != nil {
+		return nil, err
+	}
+	if req.MonthlyQuota != nil {
+		client.MonthlyQuota = req.MonthlyQuota
+		if *req.MonthlyQuota == 0 {
+			client.MonthlyQuota = nil
+		}
+	}
+	if req.QuotaWarningPercent != nil {
+		client.QuotaWarningPercent = *req.QuotaWarningPercent
+	}
+	if req.EnforceQuota != nil {
+		client.EnforceQuota = *req.EnforceQuota
+	}
+	if err := validateQuota(client.MonthlyQuota, client.QuotaWarningPercent); err != nil {
+		return nil, err
+	}
+
+	client.UpdatedAt = s.now()
+	if err := s.repo.UpdateClient(ctx, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// RotateSecret replaces the secret of a client and returns the new one, which is only
+// shown once. Tokens issued with the previous secret are rejected from now on.
+func (s *Service) RotateSecret(ctx context.Context, festivalID, id uuid.UUID) (*ClientWithSecret, error) {
+	client, err := s.GetClient(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if client.Status == ClientStatusRevoked {
+		return nil, ErrClientRevoked
+	}
+
+	secret, err := generateClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	client.SecretHash = hashSecret(secret)
+	client.SecretPrefix = secret[:len(ClientSecretPrefix)+6]
+	client.SecretRotatedAt = now
+	client.UpdatedAt = now
+	if err := s.repo.UpdateClient(ctx, client); err != nil {
+		return nil, err
+	}
+
+	return &ClientWithSecret{Client: *client, ClientSecret: secret}, nil
+}
+
+// RevokeClient revokes a client for good, along with the tokens it was issued
+func (s *Service) RevokeClient(ctx context.Context, festivalID, id uuid.UUID) (*Client, error) {
+	client, err := s.GetClient(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if client.Status == ClientStatusRevoked {
+		return client, nil
+	}
+
+	now := s.now()
+	client.Status = ClientStatusRevoked
+	client.RevokedAt = &now
+	client.UpdatedAt = now
+	if err := s.repo.UpdateClient(ctx, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// DeleteClient deletes a client with its usage
+func (s *Service) DeleteClient(ctx context.Context, festivalID, id uuid.UUID) error {
+	if _, err := s.GetClient(ctx, festivalID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteClient(ctx, id)
+}
+
+// GetUsage reports the metered usage of a client, hour by hour
+func (s *Service) GetUsage(ctx context.Context, festivalID, id uuid.UUID, query UsageQuery) (*UsageReport, error) {
+	client, err := s.GetClient(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	to := now.Truncate(time.Hour).Add(time.Hour)
+	if query.To != nil {
+		to = query.To.UTC()
+	}
+	from := to.Add(-24 * time.Hour)
+	if query.From != nil {
+		from = query.From.UTC().Truncate(time.Hour)
+	}
+	if !to.After(from) || to.Sub(from) > MaxUsagePeriod {
+		return nil, ErrInvalidUsagePeriod
+	}
+
+	hours, err := s.repo.ListUsage(ctx, client.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{
+		ClientID:          client.ID,
+		From:              from,
+		To:                to,
+		RequestsPerMinute: client.RequestsPerMinute,
+		RequestsPerDay:    client.RequestsPerDay,
+		Hours:             hours,
+	}
+	for _, hour := range hours {
+		report.Requests += hour.Requests
+		report.Errors += hour.Errors
+		report.Throttled += hour.Throttled
+		report.TokensIssued += hour.TokensIssued
+	}
+
+	today, err := s.repo.ListUsage(ctx, client.ID, now.Truncate(24*time.Hour), now.Add(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	for _, hour := range today {
+		report.RequestsToday += hour.Requests
+	}
+
+	return report, nil
+}
+
+// UsageDashboard reports the usage of every client of a festival over a month, by
+// endpoint class and day, against their quotas. The clients past the warning threshold
+// of their quota are listed in the warnings.
+func (s *Service) UsageDashboard(ctx context.Context, festivalID uuid.UUID, query UsageDashboardQuery) (*UsageDashboard, error) {
+	from := monthStart(s.now().UTC())
+	if query.Month != "" {
+		month, err := time.Parse("2006-01", query.Month)
+		if err != nil {
+			return nil, ErrInvalidMonth
+		}
+		from = month
+	}
+	to := from.AddDate(0, 1, 0)
+
+	clients, err := s.repo.ListClients(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.repo.ListDailyUsage(ctx, festivalID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byClient := make(map[uuid.UUID][]DailyUsage)
+	for _, day := range days {
+		byClient[day.ClientID] = append(byClient[day.ClientID], day)
+	}
+
+	dashboard := &UsageDashboard{
+		FestivalID: festivalID,
+		Month:      from.Format("2006-01"),
+		From:       from,
+		To:         to,
+		Clients:    make([]ClientQuota, 0, len(clients)),
+		Warnings:   []QuotaWarning{},
+	}
+	for i := range clients {
+		usage := summarizeUsage(&clients[i], byClient[clients[i].ID])
+		dashboard.Requests += usage.Requests
+		dashboard.Clients = append(dashboard.Clients, usage)
+
+		if usage.QuotaStatus == QuotaStatusWarning || usage.QuotaStatus == QuotaStatusExceeded {
+			dashboard.Warnings = append(dashboard.Warnings, QuotaWarning{
+				ClientID:    usage.ClientID,
+				Name:        usage.Name,
+				QuotaStatus: usage.QuotaStatus,
+				Message: fmt.Sprintf("%s made %d of the %d requests of its monthly quota (%.1f%%)",
+					usage.Name, usage.Requests, *usage.MonthlyQuota, usage.QuotaUsedPercent),
+			})
+		}
+	}
+
+	return dashboard, nil
+}
+
+// IssueToken runs the client credentials grant, returning an access token carrying the
+// requested scopes, or every scope of the client when none is requested
+func (s *Service) IssueToken(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	if req.GrantType != GrantTypeClientCredentials {
+		return nil, ErrUnsupportedGrant
+	}
+
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := client.Scopes
+	if requested := strings.Fields(req.Scope); len(requested) > 0 {
+		scopes, err = normalizeScopes(requested)
+		if err != nil {
+			return nil, err
+		}
+		for _, scope := range scopes {
+			if !client.HasScope(scope) {
+				return nil, fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+			}
+		}
+	}
+
+	now := s.now()
+	scope := joinScopes(scopes)
+	secret := s.keyring.Current()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		FestivalID: client.FestivalID.String(),
+		ClientID:   client.ClientID,
+		Scope:      scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			Subject:   client.ID.String(),
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
+		},
+	})
+	token.Header["kid"] = security.Fingerprint(secret)
+
+	signed, err := token.SignedString(tokenKey(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	s.Meter(ctx, client, UsageEvent{TokenIssued: true})
+
+	return &TokenResponse{
+		AccessToken: signed,
+		TokenType:   TokenType,
+		ExpiresIn:   int(TokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// Authenticate verifies an access token and returns its client. Tokens of revoked
+// clients, or issued before the last secret rotation, are rejected, and scopes
+// removed from the client since the token was issued no longer apply.
+func (s *Service) Authenticate(ctx context.Context, accessToken string) (*Principal, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(s.now),
+	)
+
+	var claims tokenClaims
+	_, err := parser.ParseWithClaims(accessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, secret := range s.keyring.Accepted() {
+			if security.Fingerprint(secret) == kid {
+				return tokenKey(secret), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	client, err := s.repo.GetClientByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.Status != ClientStatusActive {
+		return nil, ErrInvalidToken
+	}
+	if claims.IssuedAt.Time.Before(client.SecretRotatedAt.Truncate(time.Second)) {
+		return nil, ErrInvalidToken
+	}
+
+	var scopes []auth.PermissionString
+	for _, scope := range strings.Fields(claims.Scope) {
+		if client.HasScope(auth.PermissionString(scope)) {
+			scopes = append(scopes, auth.PermissionString(scope))
+		}
+	}
+
+	return &Principal{Client: client, Scopes: scopes}, nil
+}
+
+// Allow counts a request of the client against its rate limits. It returns how long
+// to wait when a limit is reached. Requests are allowed when Redis is unavailable.
+func (s *Service) Allow(ctx context.Context, client *Client) (bool, time.Duration) {
+	if s.redis == nil {
+		return true, 0
+	}
+
+	now := s.now().UTC()
+	minute := now.Truncate(time.Minute)
+	minuteKey := fmt.Sprintf("oauth:ratelimit:%s:minute:%d", client.ID, minute.Unix())
+	count, err := s.incr(ctx, minuteKey, 2*time.Minute)
+	if err != nil {
+		log.Warn().Err(err).Str("client_id", client.ClientID).Msg("OAuth client rate limit not enforced")
+		return true, 0
+	}
+	if count > int64(client.RequestsPerMinute) {
+		return false, minute.Add(time.Minute).Sub(now)
+	}
+
+	day := now.Truncate(24 * time.Hour)
+	dayKey := fmt.Sprintf("oauth:ratelimit:%s:day:%s", client.ID, day.Format("2006-01-02"))
+	count, err = s.incr(ctx, dayKey, 25*time.Hour)
+	if err != nil {
+		log.Warn().Err(err).Str("client_id", client.ClientID).Msg("OAuth client rate limit not enforced")
+		return true, 0
+	}
+	if count > int64(client.RequestsPerDay) {
+		return false, day.Add(24 * time.Hour).Sub(now)
+	}
+
+	return true, 0
+}
+
+// CountQuota counts a request of the client against its monthly quota. It returns nil
+// when the client has no quota or Redis is unavailable. The counter of a month starts
+// from the metered requests of the month, so it survives Redis losing its data.
+func (s *Service) CountQuota(ctx context.Context, client *Client) *QuotaCheck {
+	if s.redis == nil || client.MonthlyQuota == nil {
+		return nil
+	}
+
+	now := s.now().UTC()
+	month := monthStart(now)
+	next := month.AddDate(0, 1, 0)
+	key := fmt.Sprintf("oauth:quota:%s:%s", client.ID, month.Format("2006-01"))
+	used, err := s.incr(ctx, key, next.Sub(now)+24*time.Hour)
+	if err != nil {
+		log.Warn().Err(err).Str("client_id", client.ClientID).Msg("OAuth client quota not counted")
+		return nil
+	}
+	if used == 1 {
+		metered, err := s.repo.CountRequests(ctx, client.ID, month, next)
+		if err != nil {
+			log.Warn().Err(err).Str("client_id", client.ClientID).Msg("Failed to resume OAuth client quota")
+		} else if metered > 0 {
+			if used, err = s.redis.IncrBy(ctx, key, metered).Result(); err != nil {
+				log.Warn().Err(err).Str("client_id", client.ClientID).Msg("Failed to resume OAuth client quota")
+				return nil
+			}
+		}
+	}
+
+	return checkQuota(client, used, next)
+}
+
+// Meter adds an event to the usage of the client for the current hour. Metering
+// failures are logged, never returned to the client.
+func (s *Service) Meter(ctx context.Context, client *Client, event UsageEvent) {
+	now := s.now()
+	if err := s.repo.RecordUsage(ctx, client, now.UTC().Truncate(time.Hour), event); err != nil {
+		log.Error().Err(err).Str("client_id", client.ClientID).Msg("Failed to meter OAuth client usage")
+	}
+	if event.Request || event.TokenIssued {
+		if err := s.repo.TouchClient(ctx, client.ID, now); err != nil {
+			log.Error().Err(err).Str("client_id", client.ClientID).Msg("Failed to update OAuth client")
+		}
+	}
+}
+
+// GrantableScopes lists the scopes clients can be granted
+func (s *Service) GrantableScopes() []ScopeInfo {
+	scopes := grantableScopes()
+	infos := make([]ScopeInfo, 0, len(scopes))
+	for _, scope := range scopes {
+		infos = append(infos, ScopeInfo{Scope: scope, Resource: scope.Resource(), Action: scope.Action()})
+	}
+	return infos
+}
+
+func (s *Service) authenticateClient(ctx context.Context, clientID, secret string) (*Client, error) {
+	if !strings.HasPrefix(clientID, ClientIDPrefix) || !strings.HasPrefix(secret, ClientSecretPrefix) {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.repo.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.Status != ClientStatusActive {
+		return nil, ErrInvalidClient
+	}
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+func (s *Service) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := s.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// grantableScopes returns the permissions of a festival admin, except those of the
+// resources kept away from machine clients
+func grantableScopes() []auth.PermissionString {
+	var scopes []auth.PermissionString
+	for _, perm := range auth.GetPermissionsForRole(auth.RoleFestivalAdmin) {
+		if !nonGrantableResources[perm.Resource()] {
+			scopes = append(scopes, perm)
+		}
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i] < scopes[j] })
+	return scopes
+}
+
+// normalizeScopes validates scopes, expanding "resource.*" to the grantable actions of
+// the resource, and returns them sorted without duplicates
+func normalizeScopes(requested []string) ([]auth.PermissionString, error) {
+	grantable := grantableScopes()
+	seen := make(map[auth.PermissionString]bool)
+	var scopes []auth.PermissionString
+
+	add := func(scope auth.PermissionString) {
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	for _, value := range requested {
+		value = strings.TrimSpace(value)
+		if strings.HasSuffix(value, ".*") {
+			matched := false
+			for _, perm := range auth.GetWildcardPermissions(value) {
+				if auth.HasPermissionInSet(perm, grantable) {
+					add(perm)
+					matched = true
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidScope, value)
+			}
+			continue
+		}
+
+		scope := auth.PermissionString(value)
+		if !auth.HasPermissionInSet(scope, grantable) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, value)
+		}
+		add(scope)
+	}
+
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i] < scopes[j] })
+	return scopes, nil
+}
+
+func validateQuota(monthlyQuota *int64, warningPercent int) error {
+	if (monthlyQuota != nil && *monthlyQuota <= 0) || warningPercent < 1 || warningPercent > 100 {
+		return ErrInvalidQuota
+	}
+	return nil
+}
+
+// checkQuota tells where a client stands once used requests of the month are counted
+func checkQuota(client *Client, used int64, resetsAt time.Time) *QuotaCheck {
+	return &QuotaCheck{
+		Limit:    *client.MonthlyQuota,
+		Used:     used,
+		ResetsAt: resetsAt,
+		Exceeded: client.EnforceQuota && used > *client.MonthlyQuota,
+	}
+}
+
+// summarizeUsage adds up the days of a client over a month, by endpoint class and day
+func summarizeUsage(client *Client, days []DailyUsage) ClientQuota {
+	usage := ClientQuota{
+		ClientID:     client.ID,
+		Name:         client.Name,
+		Status:       client.Status,
+		MonthlyQuota: client.MonthlyQuota,
+		QuotaStatus:  QuotaStatusNone,
+		EnforceQuota: client.EnforceQuota,
+		ByClass:      []ClassUsage{},
+		Days:         []DailyRequests{},
+	}
+
+	classes := make(map[EndpointClass]*ClassUsage)
+	requestsPerDay := make(map[string]int64)
+	for _, day := range days {
+		usage.Requests += day.Requests
+		usage.Errors += day.Errors
+		usage.Throttled += day.Throttled
+		usage.QuotaExceeded += day.QuotaExceeded
+
+		class, ok := classes[day.EndpointClass]
+		if !ok {
+			class = &ClassUsage{EndpointClass: day.EndpointClass}
+			classes[day.EndpointClass] = class
+		}
+		class.Requests += day.Requests
+		class.Errors += day.Errors
+		requestsPerDay[day.Day.Format("2006-01-02")] += day.Requests
+	}
+	for _, class := range classes {
+		usage.ByClass = append(usage.ByClass, *class)
+	}
+	sort.Slice(usage.ByClass, func(i, j int) bool {
+		return usage.ByClass[i].EndpointClass < usage.ByClass[j].EndpointClass
+	})
+	for day, requests := range requestsPerDay {
+		usage.Days = append(usage.Days, DailyRequests{Day: day, Requests: requests})
+	}
+	sort.Slice(usage.Days, func(i, j int) bool { return usage.Days[i].Day < usage.Days[j].Day })
+
+	if client.MonthlyQuota != nil {
+		quota := *client.MonthlyQuota
+		usage.QuotaUsedPercent = math.Round(float64(usage.Requests)*1000/float64(quota)) / 10
+		switch {
+		case usage.Requests >= quota:
+			usage.QuotaStatus = QuotaStatusExceeded
+		case usage.Requests*100 >= quota*int64(client.QuotaWarningPercent):
+			usage.QuotaStatus = QuotaStatusWarning
+		default:
+			usage.QuotaStatus = QuotaStatusOK
+		}
+	}
+	return usage
+}
+
+// classifyEndpoint returns the class of an integration route, named after its first
+// segment under the festival
+func classifyEndpoint(route string) EndpointClass {
+	_, rest, found := strings.Cut(route, "/:id/")
+	if !found {
+		return EndpointClassOther
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	if class, ok := endpointClasses[segment]; ok {
+		return class
+	}
+	return EndpointClassOther
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func validateRateLimits(perMinute, perDay int) error {
+	if perMinute <= 0 || perDay <= 0 || perDay < perMinute {
+		return ErrInvalidRateLimit
+	}
+	return nil
+}
+
+func joinScopes(scopes []auth.PermissionString) string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return strings.Join(values, " ")
+}
+
+// tokenKey derives the access token signing key from a keyring secret
+func tokenKey(secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(tokenKeyLabel))
+	return mac.Sum(nil)
+}
+
+func generateClientID() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate client ID: %w", err)
+	}
+	return ClientIDPrefix + hex.EncodeToString(raw), nil
+}
+
+func generateClientSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate client secret: %w", err)
+	}
+	return ClientSecretPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,389 @@
+package oauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/auth"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(repo Repository) (*Service, *security.Keyring, *time.Time) {
+	keyring := security.NewKeyring("current-secret", nil, time.Hour)
+	service := NewService(repo, keyring, nil)
+	now := time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, keyring, &now
+}
+
+// registerClient registers a client and returns it along with the row saved by the
+// repository
+func registerClient(t *testing.T, service *Service, mockRepo *MockRepository, scopes ...string) (*ClientWithSecret, *Client) {
+	t.Helper()
+	stored := &Client{}
+	mockRepo.On("CreateClient", mock.Anything, mock.AnythingOfType("*oauth.Client")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Client) }).
+		Return(nil).Once()
+
+	client, err := service.RegisterClient(context.Background(), uuid.New(), nil, CreateClientRequest{
+		Name:   "Ticketing partner",
+		Scopes: scopes,
+	})
+	require.NoError(t, err)
+	return client, stored
+}
+
+// expectClient serves the saved row of a client to every lookup, so that updates are
+// seen by the next calls
+func expectClient(mockRepo *MockRepository, stored *Client) {
+	mockRepo.On("GetClient", mock.Anything, stored.FestivalID, stored.ID).Return(stored, nil)
+	mockRepo.On("GetClientByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockRepo.On("GetClientByClientID", mock.Anything, stored.ClientID).Return(stored, nil)
+	mockRepo.On("UpdateClient", mock.Anything, stored).Return(nil)
+}
+
+func TestRegisterClient(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, _ := newTestService(mockRepo)
+
+	client, stored := registerClient(t, service, mockRepo, "tickets.read", "orders.*", "tickets.read")
+	assert.Contains(t, client.ClientSecret, ClientSecretPrefix)
+	assert.Contains(t, client.ClientID, ClientIDPrefix)
+	assert.Equal(t, hashSecret(client.ClientSecret), stored.SecretHash)
+	assert.Equal(t, DefaultRequestsPerMinute, client.RequestsPerMinute)
+	assert.Equal(t, DefaultRequestsPerDay, client.RequestsPerDay)
+
+	// Wildcards are expanded and duplicates dropped
+	assert.True(t, client.HasScope(auth.PermOrdersRefund))
+	assert.True(t, client.HasScope(auth.PermTicketsRead))
+	assert.False(t, client.HasScope(auth.PermTicketsWrite))
+	count := 0
+	for _, scope := range client.Scopes {
+		if scope == auth.PermTicketsRead {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+
+	ctx := context.Background()
+	_, err := service.RegisterClient(ctx, uuid.New(), nil, CreateClientRequest{Name: "x", Scopes: []string{"roles.assign"}})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, err = service.RegisterClient(ctx, uuid.New(), nil, CreateClientRequest{Name: "x", Scopes: []string{"api.*"}})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, err = service.RegisterClient(ctx, uuid.New(), nil, CreateClientRequest{Name: "x", Scopes: []string{"tickets.fly"}})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, err = service.RegisterClient(ctx, uuid.New(), nil, CreateClientRequest{
+		Name: "x", Scopes: []string{"tickets.read"}, RequestsPerMinute: 100, RequestsPerDay: 10,
+	})
+	assert.ErrorIs(t, err, ErrInvalidRateLimit)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateClient", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIssueToken(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, now := newTestService(mockRepo)
+	ctx := context.Background()
+	client, stored := registerClient(t, service, mockRepo, "tickets.read", "orders.export")
+	expectClient(mockRepo, stored)
+
+	tokenIssued := mock.MatchedBy(func(event UsageEvent) bool {
+		return event.TokenIssued && !event.Request && event.Class == ""
+	})
+	mockRepo.On("RecordUsage", mock.Anything, mock.AnythingOfType("*oauth.Client"), now.Truncate(time.Hour), tokenIssued).Return(nil)
+	mockRepo.On("TouchClient", mock.Anything, client.ID, *now).Return(nil)
+
+	token, err := service.IssueToken(ctx, TokenRequest{
+		GrantType:    GrantTypeClientCredentials,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Scope:        "tickets.read",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TokenType, token.TokenType)
+	assert.Equal(t, 3600, token.ExpiresIn)
+	assert.Equal(t, "tickets.read", token.Scope)
+
+	principal, err := service.Authenticate(ctx, token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, principal.Client.ID)
+	assert.True(t, principal.HasScope(auth.PermTicketsRead))
+	assert.False(t, principal.HasScope(auth.PermOrdersExport))
+
+	// Every granted scope when none is requested
+	all, err := service.IssueToken(ctx, TokenRequest{
+		GrantType:    GrantTypeClientCredentials,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "orders.export tickets.read", all.Scope)
+
+	_, err = service.IssueToken(ctx, TokenRequest{
+		GrantType: GrantTypeClientCredentials, ClientID: client.ClientID, ClientSecret: client.ClientSecret, Scope: "wallets.read",
+	})
+	assert.ErrorIs(t, err, ErrScopeNotGranted)
+	_, err = service.IssueToken(ctx, TokenRequest{
+		GrantType: GrantTypeClientCredentials, ClientID: client.ClientID, ClientSecret: ClientSecretPrefix + "wrong",
+	})
+	assert.ErrorIs(t, err, ErrInvalidClient)
+	_, err = service.IssueToken(ctx, TokenRequest{
+		GrantType: "password", ClientID: client.ClientID, ClientSecret: client.ClientSecret,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedGrant)
+
+	// Only the tokens actually issued are metered
+	mockRepo.AssertNumberOfCalls(t, "RecordUsage", 2)
+	mockRepo.AssertNumberOfCalls(t, "TouchClient", 2)
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, keyring, now := newTestService(mockRepo)
+	ctx := context.Background()
+	client, stored := registerClient(t, service, mockRepo, "tickets.read", "orders.export")
+	expectClient(mockRepo, stored)
+	mockRepo.On("RecordUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("TouchClient", mock.Anything, client.ID, mock.Anything).Return(nil)
+
+	issue := func(secret string) string {
+		token, err := service.IssueToken(ctx, TokenRequest{
+			GrantType: GrantTypeClientCredentials, ClientID: client.ClientID, ClientSecret: secret,
+		})
+		require.NoError(t, err)
+		return token.AccessToken
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		token := issue(client.ClientSecret)
+		*now = now.Add(TokenTTL + time.Second)
+		_, err := service.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("keyring rotation", func(t *testing.T) {
+		token := issue(client.ClientSecret)
+		keyring.Rotate("next-secret", nil)
+		_, err := service.Authenticate(ctx, token)
+		assert.NoError(t, err, "tokens signed with the previous secret are accepted during the overlap")
+	})
+
+	t.Run("removed scopes no longer apply", func(t *testing.T) {
+		token := issue(client.ClientSecret)
+		_, err := service.UpdateClient(ctx, client.FestivalID, client.ID, UpdateClientRequest{Scopes: []string{"tickets.read"}})
+		require.NoError(t, err)
+
+		principal, err := service.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.True(t, principal.HasScope(auth.PermTicketsRead))
+		assert.False(t, principal.HasScope(auth.PermOrdersExport))
+	})
+
+	t.Run("secret rotation", func(t *testing.T) {
+		token := issue(client.ClientSecret)
+		*now = now.Add(time.Minute)
+		rotated, err := service.RotateSecret(ctx, client.FestivalID, client.ID)
+		require.NoError(t, err)
+
+		_, err = service.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		_, err = service.IssueToken(ctx, TokenRequest{
+			GrantType: GrantTypeClientCredentials, ClientID: client.ClientID, ClientSecret: client.ClientSecret,
+		})
+		assert.ErrorIs(t, err, ErrInvalidClient)
+
+		client.ClientSecret = rotated.ClientSecret
+		_, err = service.Authenticate(ctx, issue(client.ClientSecret))
+		assert.NoError(t, err)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		token := issue(client.ClientSecret)
+		_, err := service.RevokeClient(ctx, client.FestivalID, client.ID)
+		require.NoError(t, err)
+
+		_, err = service.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = service.RotateSecret(ctx, client.FestivalID, client.ID)
+		assert.ErrorIs(t, err, ErrClientRevoked)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		mockRepo.On("GetClient", mock.Anything, client.FestivalID, mock.Anything).Return(nil, nil).Once()
+		_, err := service.RevokeClient(ctx, client.FestivalID, uuid.New())
+		assert.ErrorIs(t, err, ErrClientNotFound)
+	})
+
+	_, err := service.Authenticate(ctx, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestGetUsage(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, now := newTestService(mockRepo)
+	ctx := context.Background()
+	client, stored := registerClient(t, service, mockRepo, "tickets.read")
+	mockRepo.On("GetClient", mock.Anything, client.FestivalID, client.ID).Return(stored, nil)
+
+	// Usage is metered by the hour
+	*now = now.Add(25 * time.Minute)
+	mockRepo.On("RecordUsage", mock.Anything, &client.Client, time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC),
+		UsageEvent{Request: true, Error: true}).Return(nil).Once()
+	mockRepo.On("TouchClient", mock.Anything, client.ID, *now).Return(nil).Once()
+	service.Meter(ctx, &client.Client, UsageEvent{Request: true, Error: true})
+	mockRepo.On("RecordUsage", mock.Anything, &client.Client, time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC),
+		UsageEvent{Throttled: true}).Return(nil).Once()
+	service.Meter(ctx, &client.Client, UsageEvent{Throttled: true})
+	mockRepo.AssertNumberOfCalls(t, "TouchClient", 1)
+
+	yesterday := time.Date(2026, 7, 9, 22, 0, 0, 0, time.UTC)
+	hour := time.Date(2026, 7, 10, 17, 0, 0, 0, time.UTC)
+	mockRepo.On("ListUsage", mock.Anything, client.ID, time.Date(2026, 7, 9, 19, 0, 0, 0, time.UTC), time.Date(2026, 7, 10, 19, 0, 0, 0, time.UTC)).
+		Return([]ClientUsage{
+			{ClientID: client.ID, Hour: yesterday, Requests: 4, TokensIssued: 1},
+			{ClientID: client.ID, Hour: hour, Requests: 2, Errors: 1, Throttled: 1},
+		}, nil).Once()
+	mockRepo.On("ListUsage", mock.Anything, client.ID, time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC), now.Add(time.Hour)).
+		Return([]ClientUsage{{ClientID: client.ID, Hour: hour, Requests: 2, Errors: 1, Throttled: 1}}, nil).Once()
+
+	report, err := service.GetUsage(ctx, client.FestivalID, client.ID, UsageQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), report.Requests)
+	assert.Equal(t, int64(1), report.Errors)
+	assert.Equal(t, int64(1), report.Throttled)
+	assert.Equal(t, int64(1), report.TokensIssued)
+	assert.Equal(t, int64(2), report.RequestsToday)
+	assert.Len(t, report.Hours, 2)
+
+	from := now.Add(-40 * 24 * time.Hour)
+	_, err = service.GetUsage(ctx, client.FestivalID, client.ID, UsageQuery{From: &from})
+	assert.ErrorIs(t, err, ErrInvalidUsagePeriod)
+
+	mockRepo.AssertNumberOfCalls(t, "ListUsage", 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestClientQuota(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	quota := int64(1000)
+
+	stored := &Client{}
+	mockRepo.On("CreateClient", mock.Anything, mock.AnythingOfType("*oauth.Client")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Client) }).
+		Return(nil).Once()
+	client, err := service.RegisterClient(ctx, uuid.New(), nil, CreateClientRequest{
+		Name: "Accounting sync", Scopes: []string{"orders.export"}, MonthlyQuota: &quota,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultQuotaWarningPercent, client.QuotaWarningPercent)
+	assert.False(t, client.EnforceQuota)
+	mockRepo.On("GetClient", mock.Anything, client.FestivalID, client.ID).Return(stored, nil)
+	mockRepo.On("UpdateClient", mock.Anything, stored).Return(nil)
+
+	// Requests over the quota are only refused when it is enforced
+	assert.False(t, checkQuota(&client.Client, 1001, time.Time{}).Exceeded)
+	enforce := true
+	updated, err := service.UpdateClient(ctx, client.FestivalID, client.ID, UpdateClientRequest{EnforceQuota: &enforce})
+	require.NoError(t, err)
+	assert.False(t, checkQuota(updated, 1000, time.Time{}).Exceeded)
+	assert.True(t, checkQuota(updated, 1001, time.Time{}).Exceeded)
+
+	zero, negative, tooHigh := int64(0), int64(-5), 101
+	updated, err = service.UpdateClient(ctx, client.FestivalID, client.ID, UpdateClientRequest{MonthlyQuota: &zero})
+	require.NoError(t, err)
+	assert.Nil(t, updated.MonthlyQuota, "a zero quota removes it")
+	mockRepo.AssertNumberOfCalls(t, "UpdateClient", 2)
+
+	_, err = service.UpdateClient(ctx, client.FestivalID, client.ID, UpdateClientRequest{MonthlyQuota: &negative})
+	assert.ErrorIs(t, err, ErrInvalidQuota)
+	_, err = service.UpdateClient(ctx, client.FestivalID, client.ID, UpdateClientRequest{QuotaWarningPercent: &tooHigh})
+	assert.ErrorIs(t, err, ErrInvalidQuota)
+	mockRepo.AssertNumberOfCalls(t, "UpdateClient", 2)
+}
+
+func TestUsageDashboard(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	festivalID := uuid.New()
+	quota := int64(4)
+
+	limited := Client{
+		ID: uuid.New(), FestivalID: festivalID, Name: "Accounting sync", Status: ClientStatusActive,
+		MonthlyQuota: &quota, QuotaWarningPercent: 75,
+	}
+	unlimited := Client{
+		ID: uuid.New(), FestivalID: festivalID, Name: "BI", Status: ClientStatusActive,
+		QuotaWarningPercent: DefaultQuotaWarningPercent,
+	}
+	mockRepo.On("ListClients", mock.Anything, festivalID).Return([]Client{limited, unlimited}, nil)
+
+	july, august := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	day1, day2 := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 11, 0, 0, 0, 0, time.UTC)
+	days := []DailyUsage{
+		{ClientID: limited.ID, FestivalID: festivalID, Day: day1, EndpointClass: EndpointClassExports, Requests: 2, Errors: 1, Throttled: 1},
+		{ClientID: limited.ID, FestivalID: festivalID, Day: day2, EndpointClass: EndpointClassOther, Requests: 1},
+		{ClientID: unlimited.ID, FestivalID: festivalID, Day: day2, EndpointClass: EndpointClassExports, Requests: 1},
+	}
+	mockRepo.On("ListDailyUsage", mock.Anything, festivalID, july, august).Return(days, nil).Once()
+
+	dashboard, err := service.UsageDashboard(ctx, festivalID, UsageDashboardQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-07", dashboard.Month)
+	assert.Equal(t, int64(4), dashboard.Requests)
+	require.Len(t, dashboard.Clients, 2)
+
+	byID := map[uuid.UUID]ClientQuota{}
+	for _, usage := range dashboard.Clients {
+		byID[usage.ClientID] = usage
+	}
+	usage := byID[limited.ID]
+	assert.Equal(t, int64(3), usage.Requests)
+	assert.Equal(t, int64(1), usage.Errors)
+	assert.Equal(t, int64(1), usage.Throttled)
+	assert.Equal(t, 75.0, usage.QuotaUsedPercent)
+	assert.Equal(t, QuotaStatusWarning, usage.QuotaStatus)
+	assert.Equal(t, []ClassUsage{
+		{EndpointClass: EndpointClassExports, Requests: 2, Errors: 1},
+		{EndpointClass: EndpointClassOther, Requests: 1},
+	}, usage.ByClass)
+	assert.Equal(t, []DailyRequests{{Day: "2026-07-10", Requests: 2}, {Day: "2026-07-11", Requests: 1}}, usage.Days)
+	assert.Equal(t, QuotaStatusNone, byID[unlimited.ID].QuotaStatus)
+
+	require.Len(t, dashboard.Warnings, 1)
+	assert.Equal(t, limited.ID, dashboard.Warnings[0].ClientID)
+
+	days = append(days, DailyUsage{ClientID: limited.ID, FestivalID: festivalID, Day: day2, EndpointClass: EndpointClassExports, Requests: 1})
+	mockRepo.On("ListDailyUsage", mock.Anything, festivalID, july, august).Return(days, nil).Once()
+	dashboard, err = service.UsageDashboard(ctx, festivalID, UsageDashboardQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, QuotaStatusExceeded, dashboard.Warnings[0].QuotaStatus)
+
+	// Previous months are kept apart
+	mockRepo.On("ListDailyUsage", mock.Anything, festivalID, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), july).
+		Return([]DailyUsage{}, nil).Once()
+	dashboard, err = service.UsageDashboard(ctx, festivalID, UsageDashboardQuery{Month: "2026-06"})
+	require.NoError(t, err)
+	assert.Zero(t, dashboard.Requests)
+	assert.Empty(t, dashboard.Warnings)
+
+	_, err = service.UsageDashboard(ctx, festivalID, UsageDashboardQuery{Month: "July"})
+	assert.ErrorIs(t, err, ErrInvalidMonth)
+	mockRepo.AssertNumberOfCalls(t, "ListDailyUsage", 3)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestClassifyEndpoint(t *testing.T) {
+	assert.Equal(t, EndpointClassExports, classifyEndpoint("/api/v1/integrations/festivals/:id/exports/orders"))
+	assert.Equal(t, EndpointClassOther, classifyEndpoint("/api/v1/integrations/festivals/:id/lineup"))
+	assert.Equal(t, EndpointClassOther, classifyEndpoint(""))
+}
@@ -0,0 +1,62 @@
+package occupancy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterAttendeeRoutes registers the festival-scoped occupancy overlay of the map,
+// open to every authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	r.GET("/map/occupancy", h.GetOverlay)
+}
+
+// GetOverlay returns the occupancy overlay of the festival map
+// @Summary Get map occupancy overlay
+// @Description GeoJSON FeatureCollection of the map zones and stands, each with a density bucket (UNKNOWN, LOW, MODERATE, HIGH, FULL) from the zone check-ins, the attendee app locations and the stand wait times. Refreshed every 30 seconds; send the ETag back in If-None-Match to get a 304 while it did not change.
+// @Tags map
+// @Produce application/geo+json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param If-None-Match header string false "ETag of the overlay the app has"
+// @Success 200 {object} FeatureCollection "Occupancy overlay"
+// @Success 304 "Not modified"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/map/occupancy [get]
+func (h *Handler) GetOverlay(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	data, err := h.service.Overlay(c.Request.Context(), festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(RefreshInterval.Seconds())))
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/geo+json", data)
+}
@@ -0,0 +1,112 @@
+package occupancy
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Density is how crowded an area of the festival map is
+type Density string
+
+const (
+	DensityUnknown  Density = "UNKNOWN" // No check-in, ping or wait time to go by
+	DensityLow      Density = "LOW"
+	DensityModerate Density = "MODERATE"
+	DensityHigh     Density = "HIGH"
+	DensityFull     Density = "FULL"
+)
+
+// Kinds of overlay features
+const (
+	FeatureKindZone  = "zone"
+	FeatureKindStand = "stand"
+)
+
+// Sources of the density of a zone
+const (
+	SourceCheckIns = "check_ins" // Net entry scans against the zone capacity
+	SourcePings    = "pings"     // Attendee app locations, relative to the busiest zone
+)
+
+const (
+	// CheckInWindow is how far back the entry and exit scans of a zone are counted
+	CheckInWindow = 24 * time.Hour
+	// PingWindow is how recent the last location of an attendee app must be to count
+	PingWindow = 15 * time.Minute
+	// RefreshInterval is how long an overlay is served before it is computed again
+	RefreshInterval = 30 * time.Second
+)
+
+// Zone is an area of the festival map the overlay colors
+type Zone struct {
+	ID           uuid.UUID
+	Name         string
+	Type         string
+	Coordinates  []Coordinate // Polygon, empty when the zone is a point only
+	CenterLat    float64
+	CenterLng    float64
+	Capacity     *int
+	MaxOccupancy *int // From the zone metadata, used when the capacity is not set
+}
+
+// Coordinate is a vertex of a zone polygon, stored like the map zones store them
+type Coordinate struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Stand is a stand placed on the festival map by a point of interest
+type Stand struct {
+	StandID   uuid.UUID
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// CheckIns are the entry and exit scans at the gate of a zone
+type CheckIns struct {
+	ZoneID  uuid.UUID
+	Entries int64
+	Exits   int64
+}
+
+// PingCell is a cell of about 11 m of the map and the attendee apps last located in it
+type PingCell struct {
+	Latitude  float64
+	Longitude float64
+	Sessions  int64
+}
+
+// FeatureCollection is the GeoJSON overlay of a festival map
+type FeatureCollection struct {
+	Type        string    `json:"type"` // Always FeatureCollection
+	Features    []Feature `json:"features"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	RefreshIn   int       `json:"refreshIn"` // Seconds until a newer overlay is available
+}
+
+// Feature is a zone or a stand of the overlay
+type Feature struct {
+	Type       string            `json:"type"` // Always Feature
+	ID         string            `json:"id"`
+	Geometry   Geometry          `json:"geometry"`
+	Properties FeatureProperties `json:"properties"`
+}
+
+// Geometry is a GeoJSON Point, [lng, lat], or Polygon, one closed ring of [lng, lat]
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// FeatureProperties describe how crowded a zone or stand is
+type FeatureProperties struct {
+	Kind        string  `json:"kind"`
+	Name        string  `json:"name"`
+	ZoneType    string  `json:"zoneType,omitempty"`
+	Density     Density `json:"density"`
+	Occupancy   *int    `json:"occupancy,omitempty"`   // Percent of the zone capacity, from check-ins
+	Source      string  `json:"source,omitempty"`      // What the zone density comes from
+	WaitMinutes *int    `json:"waitMinutes,omitempty"` // Estimated wait at the stand
+}
@@ -0,0 +1,130 @@
+package occupancy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository reads the map, the gate scans and the attendee app locations the overlay
+// is computed from; it owns no table
+type Repository interface {
+	// ListZones returns the visible zones of the festival map
+	ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error)
+	// ListStands returns the stands placed on the festival map
+	ListStands(ctx context.Context, festivalID uuid.UUID) ([]Stand, error)
+	// ListCheckIns counts the successful entry and exit scans since a time per zone, the
+	// scans being matched to the zone named like their location
+	ListCheckIns(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]CheckIns, error)
+	// ListPingCells counts the attendee app sessions per map cell of their last location
+	// since a time
+	ListPingCells(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]PingCell, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error) {
+	var rows []struct {
+		ID           uuid.UUID
+		Name         string
+		Type         string
+		Coordinates  []byte
+		CenterLat    float64
+		CenterLng    float64
+		Capacity     *int
+		MaxOccupancy *int
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT id, name, type, coordinates,
+			COALESCE(center_lat, 0) AS center_lat, COALESCE(center_lng, 0) AS center_lng,
+			capacity, (metadata->>'maxOccupancy')::INTEGER AS max_occupancy
+		FROM map_zones
+		WHERE festival_id = ? AND is_visible
+		ORDER BY sort_order, name`, festivalID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list map zones: %w", err)
+	}
+
+	zones := make([]Zone, len(rows))
+	for i, row := range rows {
+		zones[i] = Zone{
+			ID:           row.ID,
+			Name:         row.Name,
+			Type:         row.Type,
+			CenterLat:    row.CenterLat,
+			CenterLng:    row.CenterLng,
+			Capacity:     row.Capacity,
+			MaxOccupancy: row.MaxOccupancy,
+		}
+		if len(row.Coordinates) > 0 {
+			if err := json.Unmarshal(row.Coordinates, &zones[i].Coordinates); err != nil {
+				return nil, fmt.Errorf("failed to decode map zone %s: %w", row.ID, err)
+			}
+		}
+	}
+	return zones, nil
+}
+
+func (r *repository) ListStands(ctx context.Context, festivalID uuid.UUID) ([]Stand, error) {
+	var stands []Stand
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (stand_id) stand_id, name, latitude, longitude
+		FROM map_pois
+		WHERE festival_id = ? AND stand_id IS NOT NULL AND status <> 'INACTIVE'
+		ORDER BY stand_id, is_featured DESC, sort_order`, festivalID).
+		Scan(&stands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list map stands: %w", err)
+	}
+	return stands, nil
+}
+
+func (r *repository) ListCheckIns(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]CheckIns, error) {
+	var checkIns []CheckIns
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT z.id AS zone_id,
+			COUNT(*) FILTER (WHERE s.scan_type = 'ENTRY') AS entries,
+			COUNT(*) FILTER (WHERE s.scan_type = 'EXIT') AS exits
+		FROM ticket_scans s
+		JOIN map_zones z ON z.festival_id = s.festival_id AND LOWER(z.name) = LOWER(s.location)
+		WHERE s.festival_id = ? AND s.scanned_at >= ?
+			AND s.result = 'SUCCESS' AND s.scan_type IN ('ENTRY', 'EXIT')
+		GROUP BY z.id`, festivalID, since).
+		Scan(&checkIns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count zone check-ins: %w", err)
+	}
+	return checkIns, nil
+}
+
+func (r *repository) ListPingCells(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]PingCell, error) {
+	var cells []PingCell
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT ROUND(latitude::NUMERIC, 4)::FLOAT8 AS latitude,
+			ROUND(longitude::NUMERIC, 4)::FLOAT8 AS longitude,
+			COUNT(*) AS sessions
+		FROM (
+			SELECT DISTINCT ON (session_id) latitude, longitude
+			FROM public.analytics_events
+			WHERE festival_id = ? AND timestamp >= ?
+				AND latitude IS NOT NULL AND longitude IS NOT NULL
+			ORDER BY session_id, timestamp DESC
+		) last_locations
+		GROUP BY 1, 2`, festivalID, since).
+		Scan(&cells).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count location pings: %w", err)
+	}
+	return cells, nil
+}
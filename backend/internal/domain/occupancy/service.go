@@ -0,0 +1,296 @@
+package occupancy
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// overlayCacheTTL keeps the last overlay around well past its refresh, so that requests
+// arriving while another one computes the next overlay are served the previous one
+const overlayCacheTTL = 10 * RefreshInterval
+
+// relativePingCeiling is the share of the capacity the busiest zone is taken to be at
+// when zone densities come from attendee app locations only. Pings are a sample of the
+// crowd, so they can tell which zones are busier but never that a zone is full.
+const relativePingCeiling = 0.75
+
+// WaitTimes provides the estimated wait in minutes per stand; satisfied by
+// *order.WaitTimeService
+type WaitTimes interface {
+	GetWaitMinutes(ctx context.Context, festivalID uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+// Service builds the occupancy overlay of the festival maps: the zones colored by their
+// check-ins or the attendee app locations in them and the stands by their wait time, as
+// one GeoJSON document the attendee apps poll. The overlay is computed at most once per
+// RefreshInterval and festival, whatever the number of apps polling it.
+type Service struct {
+	repo        Repository
+	waitTimes   WaitTimes
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	now         func() time.Time
+}
+
+// NewService creates the map occupancy service; redisClient may be nil to compute the
+// overlay on every request
+func NewService(repo Repository, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+		now:         time.Now,
+	}
+}
+
+// SetWaitTimes colors the stands by their estimated wait
+func (s *Service) SetWaitTimes(waitTimes WaitTimes) {
+	s.waitTimes = waitTimes
+}
+
+// Overlay returns the GeoJSON occupancy overlay of a festival map, encoded. A cached
+// overlay is served until it is RefreshInterval old; the first request after that
+// computes the next one while the others keep being served the cached one.
+func (s *Service) Overlay(ctx context.Context, festivalID uuid.UUID) ([]byte, error) {
+	if s.redisClient == nil {
+		return s.computeEncoded(ctx, festivalID)
+	}
+
+	key := s.keyBuilder.FestivalMapOccupancyKey(festivalID)
+	cached, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil && err != redis.Nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to get cached map occupancy")
+	}
+
+	refresh, err := s.redisClient.SetNX(ctx, s.keyBuilder.FestivalMapOccupancyRefreshKey(festivalID), 1, RefreshInterval).Result()
+	if err != nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to lock map occupancy refresh")
+		refresh = true
+	}
+	if len(cached) > 0 && !refresh {
+		return cached, nil
+	}
+
+	data, err := s.computeEncoded(ctx, festivalID)
+	if err != nil {
+		if len(cached) > 0 {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to refresh map occupancy, serving the previous overlay")
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := s.redisClient.Set(ctx, key, data, overlayCacheTTL).Err(); err != nil {
+		log.Warn().Err(err).Msg("Failed to cache map occupancy")
+	}
+	return data, nil
+}
+
+func (s *Service) computeEncoded(ctx context.Context, festivalID uuid.UUID) ([]byte, error) {
+	overlay, err := s.Compute(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(overlay)
+}
+
+// Compute builds the occupancy overlay of a festival map from the current check-ins,
+// attendee app locations and wait times
+func (s *Service) Compute(ctx context.Context, festivalID uuid.UUID) (*FeatureCollection, error) {
+	now := s.now()
+	zones, err := s.repo.ListZones(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	stands, err := s.repo.ListStands(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	checkIns, err := s.repo.ListCheckIns(ctx, festivalID, now.Add(-CheckInWindow))
+	if err != nil {
+		return nil, err
+	}
+	cells, err := s.repo.ListPingCells(ctx, festivalID, now.Add(-PingWindow))
+	if err != nil {
+		return nil, err
+	}
+	var waits map[uuid.UUID]int
+	if s.waitTimes != nil {
+		if waits, err = s.waitTimes.GetWaitMinutes(ctx, festivalID); err != nil {
+			return nil, err
+		}
+	}
+
+	overlay := &FeatureCollection{
+		Type:        "FeatureCollection",
+		Features:    make([]Feature, 0, len(zones)+len(stands)),
+		GeneratedAt: now.UTC(),
+		RefreshIn:   int(RefreshInterval / time.Second),
+	}
+	overlay.Features = append(overlay.Features, zoneFeatures(zones, checkIns, cells)...)
+	for _, stand := range stands {
+		overlay.Features = append(overlay.Features, standFeature(stand, waits))
+	}
+	return overlay, nil
+}
+
+// zoneFeatures colors the zones. A zone whose gate is scanned is as full as the
+// attendees checked in against its capacity; the other zones with a polygon are compared
+// to each other by the attendee apps last located in them.
+func zoneFeatures(zones []Zone, checkIns []CheckIns, cells []PingCell) []Feature {
+	scans := make(map[uuid.UUID]CheckIns, len(checkIns))
+	for _, c := range checkIns {
+		scans[c.ZoneID] = c
+	}
+
+	// Only the zones without check-ins are compared by pings
+	pings := make([]int64, len(zones))
+	var maxPings int64
+	for i, zone := range zones {
+		if _, scanned := scans[zone.ID]; (scanned && zoneCapacity(zone) > 0) || len(zone.Coordinates) < 3 {
+			continue
+		}
+		for _, cell := range cells {
+			if containsPoint(zone.Coordinates, cell.Latitude, cell.Longitude) {
+				pings[i] += cell.Sessions
+			}
+		}
+		if pings[i] > maxPings {
+			maxPings = pings[i]
+		}
+	}
+
+	features := make([]Feature, 0, len(zones))
+	for i, zone := range zones {
+		geometry, ok := zoneGeometry(zone)
+		if !ok {
+			continue
+		}
+
+		properties := FeatureProperties{
+			Kind:     FeatureKindZone,
+			Name:     zone.Name,
+			ZoneType: zone.Type,
+			Density:  DensityUnknown,
+		}
+		capacity := zoneCapacity(zone)
+		if scan, scanned := scans[zone.ID]; scanned && capacity > 0 {
+			inside := scan.Entries - scan.Exits
+			if inside < 0 {
+				inside = 0
+			}
+			occupancy := int(math.Round(float64(inside) * 100 / float64(capacity)))
+			properties.Density = densityOf(float64(inside) / float64(capacity))
+			properties.Occupancy = &occupancy
+			properties.Source = SourceCheckIns
+		} else if maxPings > 0 && len(zone.Coordinates) >= 3 {
+			properties.Density = densityOf(relativePingCeiling * float64(pings[i]) / float64(maxPings))
+			properties.Source = SourcePings
+		}
+
+		features = append(features, Feature{
+			Type:       "Feature",
+			ID:         zone.ID.String(),
+			Geometry:   geometry,
+			Properties: properties,
+		})
+	}
+	return features
+}
+
+// standFeature colors a stand by its estimated wait. Stands the wait times leave out
+// had no recent order, so nobody is waiting there.
+func standFeature(stand Stand, waits map[uuid.UUID]int) Feature {
+	properties := FeatureProperties{
+		Kind:    FeatureKindStand,
+		Name:    stand.Name,
+		Density: DensityUnknown,
+	}
+	if waits != nil {
+		minutes := waits[stand.StandID]
+		properties.Density = waitDensity(minutes)
+		properties.WaitMinutes = &minutes
+	}
+	return Feature{
+		Type:       "Feature",
+		ID:         stand.StandID.String(),
+		Geometry:   Geometry{Type: "Point", Coordinates: []float64{stand.Longitude, stand.Latitude}},
+		Properties: properties,
+	}
+}
+
+// zoneGeometry returns the polygon of a zone, or its center when it has no polygon
+func zoneGeometry(zone Zone) (Geometry, bool) {
+	if len(zone.Coordinates) >= 3 {
+		ring := make([][]float64, 0, len(zone.Coordinates)+1)
+		for _, c := range zone.Coordinates {
+			ring = append(ring, []float64{c.Longitude, c.Latitude})
+		}
+		first, last := zone.Coordinates[0], zone.Coordinates[len(zone.Coordinates)-1]
+		if first != last {
+			ring = append(ring, []float64{first.Longitude, first.Latitude})
+		}
+		return Geometry{Type: "Polygon", Coordinates: [][][]float64{ring}}, true
+	}
+	if zone.CenterLat == 0 && zone.CenterLng == 0 {
+		return Geometry{}, false
+	}
+	return Geometry{Type: "Point", Coordinates: []float64{zone.CenterLng, zone.CenterLat}}, true
+}
+
+func zoneCapacity(zone Zone) int {
+	if zone.Capacity != nil && *zone.Capacity > 0 {
+		return *zone.Capacity
+	}
+	if zone.MaxOccupancy != nil && *zone.MaxOccupancy > 0 {
+		return *zone.MaxOccupancy
+	}
+	return 0
+}
+
+// densityOf buckets the share of its capacity an area is at
+func densityOf(ratio float64) Density {
+	switch {
+	case ratio >= 0.9:
+		return DensityFull
+	case ratio >= 0.7:
+		return DensityHigh
+	case ratio >= 0.4:
+		return DensityModerate
+	default:
+		return DensityLow
+	}
+}
+
+// waitDensity buckets the estimated wait at a stand
+func waitDensity(minutes int) Density {
+	switch {
+	case minutes >= 30:
+		return DensityFull
+	case minutes >= 15:
+		return DensityHigh
+	case minutes >= 5:
+		return DensityModerate
+	default:
+		return DensityLow
+	}
+}
+
+// containsPoint tells whether a point is inside a polygon, by ray casting
+func containsPoint(polygon []Coordinate, lat, lng float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > lat) != (b.Latitude > lat) &&
+			lng < (b.Longitude-a.Longitude)*(lat-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,187 @@
+package occupancy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	zones    []Zone
+	stands   []Stand
+	checkIns []CheckIns
+	cells    []PingCell
+	since    map[string]time.Time
+}
+
+func (r *fakeRepository) ListZones(ctx context.Context, festivalID uuid.UUID) ([]Zone, error) {
+	return r.zones, nil
+}
+
+func (r *fakeRepository) ListStands(ctx context.Context, festivalID uuid.UUID) ([]Stand, error) {
+	return r.stands, nil
+}
+
+func (r *fakeRepository) ListCheckIns(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]CheckIns, error) {
+	r.since["checkIns"] = since
+	return r.checkIns, nil
+}
+
+func (r *fakeRepository) ListPingCells(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]PingCell, error) {
+	r.since["pings"] = since
+	return r.cells, nil
+}
+
+type fakeWaitTimes map[uuid.UUID]int
+
+func (w fakeWaitTimes) GetWaitMinutes(ctx context.Context, festivalID uuid.UUID) (map[uuid.UUID]int, error) {
+	return w, nil
+}
+
+// square returns a zone polygon of about 110 m around a point
+func square(lat, lng float64) []Coordinate {
+	return []Coordinate{
+		{Latitude: lat - 0.0005, Longitude: lng - 0.0005},
+		{Latitude: lat - 0.0005, Longitude: lng + 0.0005},
+		{Latitude: lat + 0.0005, Longitude: lng + 0.0005},
+		{Latitude: lat + 0.0005, Longitude: lng - 0.0005},
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func featureByName(t *testing.T, overlay *FeatureCollection, name string) Feature {
+	t.Helper()
+	for _, feature := range overlay.Features {
+		if feature.Properties.Name == name {
+			return feature
+		}
+	}
+	t.Fatalf("no feature %q", name)
+	return Feature{}
+}
+
+func TestService_Compute(t *testing.T) {
+	mainStage := Zone{ID: uuid.New(), Name: "Main stage", Type: "STAGE", Coordinates: square(50.8500, 4.3500), Capacity: intPtr(1000)}
+	camping := Zone{ID: uuid.New(), Name: "Camping", Type: "CAMPING", Coordinates: square(50.8520, 4.3520)}
+	foodCourt := Zone{ID: uuid.New(), Name: "Food court", Type: "FOOD", Coordinates: square(50.8540, 4.3540)}
+	info := Zone{ID: uuid.New(), Name: "Info", Type: "GENERAL", CenterLat: 50.8560, CenterLng: 4.3560}
+	hidden := Zone{ID: uuid.New(), Name: "No geometry"}
+	bar, fries := uuid.New(), uuid.New()
+
+	repo := &fakeRepository{
+		zones: []Zone{mainStage, camping, foodCourt, info, hidden},
+		stands: []Stand{
+			{StandID: bar, Name: "Bar", Latitude: 50.8541, Longitude: 4.3541},
+			{StandID: fries, Name: "Fries", Latitude: 50.8539, Longitude: 4.3539},
+		},
+		checkIns: []CheckIns{{ZoneID: mainStage.ID, Entries: 1100, Exits: 180}},
+		cells: []PingCell{
+			{Latitude: 50.8500, Longitude: 4.3500, Sessions: 400}, // Main stage, counted by check-ins
+			{Latitude: 50.8521, Longitude: 4.3519, Sessions: 40},  // Camping
+			{Latitude: 50.8540, Longitude: 4.3540, Sessions: 25},  // Food court
+			{Latitude: 50.8541, Longitude: 4.3542, Sessions: 15},  // Food court
+			{Latitude: 50.9000, Longitude: 4.4000, Sessions: 90},  // Outside the zones
+		},
+		since: make(map[string]time.Time),
+	}
+	now := time.Date(2026, 7, 18, 21, 0, 0, 0, time.UTC)
+	service := NewService(repo, nil)
+	service.now = func() time.Time { return now }
+	service.SetWaitTimes(fakeWaitTimes{bar: 22})
+
+	overlay, err := service.Compute(context.Background(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "FeatureCollection", overlay.Type)
+	assert.Equal(t, 30, overlay.RefreshIn)
+	assert.Len(t, overlay.Features, 6, "zones without geometry are left out")
+	assert.Equal(t, now.Add(-CheckInWindow), repo.since["checkIns"])
+	assert.Equal(t, now.Add(-PingWindow), repo.since["pings"])
+
+	stage := featureByName(t, overlay, "Main stage")
+	assert.Equal(t, DensityFull, stage.Properties.Density)
+	assert.Equal(t, SourceCheckIns, stage.Properties.Source)
+	require.NotNil(t, stage.Properties.Occupancy)
+	assert.Equal(t, 92, *stage.Properties.Occupancy)
+	assert.Equal(t, "Polygon", stage.Geometry.Type)
+	ring := stage.Geometry.Coordinates.([][][]float64)[0]
+	assert.Len(t, ring, 5, "the ring is closed")
+	assert.Equal(t, ring[0], ring[4])
+	assert.Equal(t, []float64{4.3495, 50.8495}, ring[0], "GeoJSON positions are [lng, lat]")
+
+	// Without check-ins the zones are compared by pings, the busiest never full
+	campingFeature := featureByName(t, overlay, "Camping")
+	assert.Equal(t, DensityHigh, campingFeature.Properties.Density)
+	assert.Equal(t, SourcePings, campingFeature.Properties.Source)
+	assert.Nil(t, campingFeature.Properties.Occupancy)
+	assert.Equal(t, DensityHigh, featureByName(t, overlay, "Food court").Properties.Density)
+
+	infoFeature := featureByName(t, overlay, "Info")
+	assert.Equal(t, "Point", infoFeature.Geometry.Type)
+	assert.Equal(t, DensityUnknown, infoFeature.Properties.Density, "no polygon to locate pings in")
+
+	barFeature := featureByName(t, overlay, "Bar")
+	assert.Equal(t, FeatureKindStand, barFeature.Properties.Kind)
+	assert.Equal(t, DensityHigh, barFeature.Properties.Density)
+	assert.Equal(t, 22, *barFeature.Properties.WaitMinutes)
+	assert.Equal(t, DensityLow, featureByName(t, overlay, "Fries").Properties.Density, "no recent order, no wait")
+
+	data, err := service.Overlay(context.Background(), uuid.New())
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "FeatureCollection", decoded["type"])
+}
+
+func TestService_ComputeWithoutSignals(t *testing.T) {
+	repo := &fakeRepository{
+		zones:  []Zone{{ID: uuid.New(), Name: "Camping", Coordinates: square(50.85, 4.35), Capacity: intPtr(500)}},
+		stands: []Stand{{StandID: uuid.New(), Name: "Bar", Latitude: 50.85, Longitude: 4.35}},
+		since:  make(map[string]time.Time),
+	}
+	service := NewService(repo, nil)
+
+	overlay, err := service.Compute(context.Background(), uuid.New())
+	require.NoError(t, err)
+	for _, feature := range overlay.Features {
+		assert.Equal(t, DensityUnknown, feature.Properties.Density, feature.Properties.Name)
+		assert.Empty(t, feature.Properties.Source)
+	}
+}
+
+func TestDensityBuckets(t *testing.T) {
+	assert.Equal(t, DensityLow, densityOf(0.39))
+	assert.Equal(t, DensityModerate, densityOf(0.4))
+	assert.Equal(t, DensityHigh, densityOf(0.7))
+	assert.Equal(t, DensityFull, densityOf(0.9))
+	assert.Equal(t, DensityFull, densityOf(1.3))
+
+	assert.Equal(t, DensityLow, waitDensity(0))
+	assert.Equal(t, DensityModerate, waitDensity(5))
+	assert.Equal(t, DensityHigh, waitDensity(15))
+	assert.Equal(t, DensityFull, waitDensity(30))
+}
+
+func TestContainsPoint(t *testing.T) {
+	// An L-shaped zone
+	polygon := []Coordinate{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 2},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 2, Longitude: 1},
+		{Latitude: 2, Longitude: 0},
+	}
+	assert.True(t, containsPoint(polygon, 0.5, 0.5))
+	assert.True(t, containsPoint(polygon, 0.5, 1.5))
+	assert.True(t, containsPoint(polygon, 1.5, 0.5))
+	assert.False(t, containsPoint(polygon, 1.5, 1.5), "the notch of the L")
+	assert.False(t, containsPoint(polygon, -0.5, 0.5))
+}
@@ -0,0 +1,133 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeCancelStaleOrders is the worker task cancelling the pending orders left unpaid
+// past the TTL of their festival
+const TypeCancelStaleOrders = "order:cancel_stale"
+
+// autoCancelBatchSize is the number of orders cancelled per statement, so a backlog
+// does not lock many orders at once
+const autoCancelBatchSize = 500
+
+// StandAutoCancelStats counts the orders of a stand cancelled for not being paid in time
+type StandAutoCancelStats struct {
+	StandID             uuid.UUID `json:"standId"`
+	StandName           string    `json:"standName"`
+	Orders              int64     `json:"orders"`              // Orders created in the period
+	AutoCancelled       int64     `json:"autoCancelled"`       // Of which cancelled unpaid
+	AutoCancelledAmount int64     `json:"autoCancelledAmount"` // Total of the cancelled orders, in cents
+	Rate                float64   `json:"rate"`                // AutoCancelled / Orders
+}
+
+// AutoCancelReport is the auto-cancel rate of the stands of a festival over a period
+type AutoCancelReport struct {
+	From          time.Time              `json:"from"`
+	To            time.Time              `json:"to"`
+	Orders        int64                  `json:"orders"`
+	AutoCancelled int64                  `json:"autoCancelled"`
+	Rate          float64                `json:"rate"`
+	Stands        []StandAutoCancelStats `json:"stands"`
+}
+
+// AutoCancelService cancels the pending orders never paid, which otherwise clutter the
+// stand queues. Festivals opt in with the pendingOrderTtlMinutes setting.
+//
+// Cancelling an order releases the stock held for it, so it goes back on sale before
+// the hold would expire.
+type AutoCancelService struct {
+	repo  Repository
+	now   func() time.Time
+	clock clock.Clock
+}
+
+func NewAutoCancelService(repo Repository) *AutoCancelService {
+	return &AutoCancelService{
+		repo:  repo,
+		now:   time.Now,
+		clock: clock.Wall{},
+	}
+}
+
+// SetClock sets the clock telling the time of festivals, so that the orders of sandbox
+// festivals are cancelled at their virtual time
+func (s *AutoCancelService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// HandleCancelStaleOrders handles the periodic cancellation of stale pending orders
+func (s *AutoCancelService) HandleCancelStaleOrders(ctx context.Context, t *asynq.Task) error {
+	cancelled, err := s.CancelStale(ctx)
+	if err != nil {
+		return err
+	}
+	if cancelled > 0 {
+		log.Info().Int64("cancelled", cancelled).Msg("Cancelled stale pending orders")
+	}
+	return nil
+}
+
+// CancelStale cancels the pending orders older than the TTL of their festival, in
+// batches, and returns how many were cancelled. An order paid while being cancelled
+// keeps its payment, as the payment saves the whole order. Festivals on a test clock
+// are passed at their virtual time.
+func (s *AutoCancelService) CancelStale(ctx context.Context) (int64, error) {
+	runs, err := clock.Runs(ctx, s.clock, s.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get test clocks: %w", err)
+	}
+
+	var total int64
+	for _, run := range runs {
+		for {
+			cancelled, err := s.repo.CancelStaleOrders(ctx, run.Now, run.Scope, autoCancelBatchSize)
+			if err != nil {
+				return total, err
+			}
+			total += cancelled
+			if cancelled < autoCancelBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// GetReport returns the auto-cancel rates of the stands of a festival for the orders
+// created in [from, to)
+func (s *AutoCancelService) GetReport(ctx context.Context, festivalID uuid.UUID, from, to time.Time) (*AutoCancelReport, error) {
+	stands, err := s.repo.GetAutoCancelStats(ctx, festivalID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AutoCancelReport{
+		From:   from,
+		To:     to,
+		Stands: make([]StandAutoCancelStats, 0, len(stands)),
+	}
+	for _, stand := range stands {
+		stand.Rate = autoCancelRate(stand.AutoCancelled, stand.Orders)
+		report.Orders += stand.Orders
+		report.AutoCancelled += stand.AutoCancelled
+		report.Stands = append(report.Stands, stand)
+	}
+	report.Rate = autoCancelRate(report.AutoCancelled, report.Orders)
+	return report, nil
+}
+
+func autoCancelRate(cancelled, orders int64) float64 {
+	if orders == 0 {
+		return 0
+	}
+	return float64(cancelled) / float64(orders)
+}
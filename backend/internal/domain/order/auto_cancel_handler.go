@@ -0,0 +1,78 @@
+package order
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// autoCancelReportDefaultPeriod is the period reported when no start is given
+const autoCancelReportDefaultPeriod = 7 * 24 * time.Hour
+
+// AutoCancelHandler exposes the auto-cancel rates of the festival stands
+type AutoCancelHandler struct {
+	service *AutoCancelService
+}
+
+func NewAutoCancelHandler(service *AutoCancelService) *AutoCancelHandler {
+	return &AutoCancelHandler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped auto-cancel report, which should be
+// restricted to organizers
+func (h *AutoCancelHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/orders/auto-cancellations", h.Report)
+}
+
+// Report returns the auto-cancel rates of the festival stands
+// @Summary Get order auto-cancel report
+// @Description Get, per stand, the orders created in a period and the ones cancelled for not being paid within the pendingOrderTtlMinutes festival setting
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param start_date query string false "Start of the period, 7 days before the end by default" format(date-time)
+// @Param end_date query string false "End of the period, now by default" format(date-time)
+// @Success 200 {object} response.Response{data=AutoCancelReport} "Auto-cancel report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/orders/auto-cancellations [get]
+func (h *AutoCancelHandler) Report(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	to := time.Now()
+	if endStr := c.Query("end_date"); endStr != "" {
+		to, err = time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			response.BadRequest(c, "INVALID_DATE", "end_date must be an RFC 3339 date-time", nil)
+			return
+		}
+	}
+	from := to.Add(-autoCancelReportDefaultPeriod)
+	if startStr := c.Query("start_date"); startStr != "" {
+		from, err = time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			response.BadRequest(c, "INVALID_DATE", "start_date must be an RFC 3339 date-time", nil)
+			return
+		}
+	}
+	if !from.Before(to) {
+		response.BadRequest(c, "INVALID_PERIOD", "start_date must be before end_date", nil)
+		return
+	}
+
+	report, err := h.service.GetReport(c.Request.Context(), festivalID, from, to)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, report)
+}
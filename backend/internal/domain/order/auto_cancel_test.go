@@ -0,0 +1,93 @@
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAutoCancelRepository struct {
+	Repository
+	stale []int64 // Orders cancelled by each successive call
+	calls int
+	now   time.Time
+	runs  []clock.Run
+	stats []StandAutoCancelStats
+}
+
+func (r *fakeAutoCancelRepository) CancelStaleOrders(ctx context.Context, now time.Time, scope clock.Scope, limit int) (int64, error) {
+	r.now = now
+	r.runs = append(r.runs, clock.Run{Now: now, Scope: scope})
+	r.calls++
+	if len(r.stale) == 0 {
+		return 0, nil
+	}
+	cancelled := r.stale[0]
+	r.stale = r.stale[1:]
+	return cancelled, nil
+}
+
+func (r *fakeAutoCancelRepository) GetAutoCancelStats(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]StandAutoCancelStats, error) {
+	return r.stats, nil
+}
+
+func TestCancelStale_Batches(t *testing.T) {
+	repo := &fakeAutoCancelRepository{stale: []int64{autoCancelBatchSize, autoCancelBatchSize, 12}}
+	service := NewAutoCancelService(repo)
+	now := time.Date(2026, 7, 18, 22, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	cancelled, err := service.CancelStale(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*autoCancelBatchSize+12), cancelled)
+	assert.Equal(t, 3, repo.calls)
+	assert.Equal(t, now, repo.now)
+}
+
+func TestCancelStale_TestClock(t *testing.T) {
+	repo := &fakeAutoCancelRepository{stale: []int64{3, 5}}
+	service := NewAutoCancelService(repo)
+	now := time.Date(2026, 7, 18, 22, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	sandbox := uuid.New()
+	virtual := now.Add(36 * time.Hour)
+	service.SetClock(clock.Fixed{sandbox: virtual})
+
+	cancelled, err := service.CancelStale(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), cancelled)
+	require.Len(t, repo.runs, 2)
+	assert.Equal(t, now, repo.runs[0].Now)
+	assert.Equal(t, []uuid.UUID{sandbox}, repo.runs[0].Scope.Exclude)
+	assert.Equal(t, virtual, repo.runs[1].Now)
+	assert.Equal(t, &sandbox, repo.runs[1].Scope.FestivalID)
+}
+
+func TestAutoCancelReport(t *testing.T) {
+	repo := &fakeAutoCancelRepository{stats: []StandAutoCancelStats{
+		{StandID: uuid.New(), StandName: "Main Bar", Orders: 200, AutoCancelled: 30, AutoCancelledAmount: 24000},
+		{StandID: uuid.New(), StandName: "Food Truck", Orders: 100, AutoCancelled: 0},
+	}}
+	service := NewAutoCancelService(repo)
+	from := time.Date(2026, 7, 11, 0, 0, 0, 0, time.UTC)
+	to := from.Add(7 * 24 * time.Hour)
+
+	report, err := service.GetReport(context.Background(), uuid.New(), from, to)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), report.Orders)
+	assert.Equal(t, int64(30), report.AutoCancelled)
+	assert.InDelta(t, 0.1, report.Rate, 1e-9)
+	require.Len(t, report.Stands, 2)
+	assert.InDelta(t, 0.15, report.Stands[0].Rate, 1e-9)
+	assert.Equal(t, float64(0), report.Stands[1].Rate)
+
+	empty, err := NewAutoCancelService(&fakeAutoCancelRepository{}).GetReport(context.Background(), uuid.New(), from, to)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), empty.Rate)
+	assert.NotNil(t, empty.Stands)
+}
@@ -0,0 +1,91 @@
+package order
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Custom field errors
+var (
+	ErrInvalidCustomField      = errors.New("invalid custom field")
+	ErrCustomFieldsUnavailable = errors.New("custom fields are not available")
+)
+
+// CustomFieldValue is the value of a custom field of the festival entered on an order.
+// The label is kept as it was when ordered.
+type CustomFieldValue struct {
+	Key           string `json:"key"`
+	Label         string `json:"label"`
+	Value         string `json:"value"`                   // Normalized text, e.g. "12", "true" or an option
+	PrintOnTicket bool   `json:"printOnTicket,omitempty"` // Printed on the stand ticket
+}
+
+// CustomFieldValues is a slice of CustomFieldValue that implements GORM's Scanner and
+// Valuer interfaces
+type CustomFieldValues []CustomFieldValue
+
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *CustomFieldValues) Scan(value interface{}) error {
+	if value == nil {
+		*v = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to scan CustomFieldValues: expected []byte, got %T", value)
+	}
+
+	return json.Unmarshal(bytes, v)
+}
+
+// Get returns the value of a custom field, or "" when the order has none
+func (v CustomFieldValues) Get(key string) string {
+	for _, field := range v {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// CustomFieldValidator checks the custom field values entered on a new order against
+// the fields of the festival, and returns them normalized in the order of the fields.
+// Errors wrap ErrInvalidCustomField. Satisfied by orderfield.Service.
+type CustomFieldValidator interface {
+	ValidateOrderFields(ctx context.Context, festivalID uuid.UUID, values map[string]string) (CustomFieldValues, error)
+}
+
+// SetCustomFieldValidator enables the custom fields organizers define on orders
+func (s *Service) SetCustomFieldValidator(validator CustomFieldValidator) {
+	s.customFields = validator
+}
+
+// applyCustomFields validates the custom field values of a new order. Required fields
+// are checked even when no value is given.
+func (s *Service) applyCustomFields(ctx context.Context, order *Order, values map[string]string) error {
+	if s.customFields == nil {
+		if len(values) > 0 {
+			return ErrCustomFieldsUnavailable
+		}
+		return nil
+	}
+
+	fields, err := s.customFields.ValidateOrderFields(ctx, order.FestivalID, values)
+	if err != nil {
+		return err
+	}
+	order.CustomFields = fields
+	return nil
+}
@@ -0,0 +1,185 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+)
+
+// Delivery errors
+var (
+	ErrInvalidDeliveryLocation = errors.New("unknown or inactive delivery location")
+	ErrStandDoesNotDeliver     = errors.New("this stand does not deliver to the location")
+	ErrDeliveryUnavailable     = errors.New("delivery is not available")
+	ErrNotForDelivery          = errors.New("order is not for delivery")
+	ErrDeliveryNotPaid         = errors.New("only paid orders can be delivered")
+	ErrAlreadyDelivered        = errors.New("order was already delivered")
+	ErrDeliveryTaken           = errors.New("order is being delivered by another runner")
+)
+
+// DeliveryTarget is the table or camping pitch an order is delivered to
+type DeliveryTarget struct {
+	LocationID uuid.UUID
+	StandID    *uuid.UUID // Only stand delivering to the location, nil for any stand
+	Label      string
+	SLA        time.Duration // Time allowed from order to delivery
+}
+
+// DeliveryLocationResolver resolves a scanned location QR code or printed location code
+// to a delivery target, or nil when no active location matches; satisfied by
+// delivery.Service
+type DeliveryLocationResolver interface {
+	ResolveDeliveryLocation(ctx context.Context, festivalID uuid.UUID, ref string) (*DeliveryTarget, error)
+}
+
+// DeliveryQueueFilter narrows the delivery queue
+type DeliveryQueueFilter struct {
+	StandID  *uuid.UUID
+	RunnerID *uuid.UUID
+	Status   *DeliveryStatus
+}
+
+// SetDeliveryLocationResolver enables orders delivered to a table or pitch
+func (s *Service) SetDeliveryLocationResolver(resolver DeliveryLocationResolver) {
+	s.deliveries = resolver
+}
+
+// applyDelivery resolves the delivery location of a new order and starts the clock of
+// its SLA
+func (s *Service) applyDelivery(ctx context.Context, order *Order, ref string) error {
+	if s.deliveries == nil {
+		return ErrDeliveryUnavailable
+	}
+
+	target, err := s.deliveries.ResolveDeliveryLocation(ctx, order.FestivalID, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve delivery location: %w", err)
+	}
+	if target == nil {
+		return ErrInvalidDeliveryLocation
+	}
+	if target.StandID != nil && *target.StandID != order.StandID {
+		return ErrStandDoesNotDeliver
+	}
+
+	status := DeliveryStatusWaiting
+	due := order.CreatedAt.Add(target.SLA)
+	order.DeliveryLocationID = &target.LocationID
+	order.DeliveryLocation = target.Label
+	order.DeliveryStatus = &status
+	order.DeliveryDueAt = &due
+	return nil
+}
+
+// DeliveryQueue returns the paid orders of a festival waiting for a runner or being
+// delivered, the closest deadline first
+func (s *Service) DeliveryQueue(ctx context.Context, festivalID uuid.UUID, filter DeliveryQueueFilter) ([]Order, error) {
+	return s.repo.GetDeliveryQueue(ctx, festivalID, filter)
+}
+
+// AssignDelivery gives a delivery to a runner. An order already taken by another runner
+// is only reassigned when reassign is set, so that two runners claiming the same order
+// do not both walk to the table.
+func (s *Service) AssignDelivery(ctx context.Context, festivalID, orderID, runnerID uuid.UUID, reassign bool) (*Order, error) {
+	order, err := s.getDelivery(ctx, festivalID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch *order.DeliveryStatus {
+	case DeliveryStatusDelivered:
+		return nil, ErrAlreadyDelivered
+	case DeliveryStatusAssigned:
+		if order.RunnerID != nil && *order.RunnerID == runnerID {
+			return order, nil
+		}
+		if !reassign {
+			return nil, ErrDeliveryTaken
+		}
+	}
+
+	from, fromRunner := *order.DeliveryStatus, order.RunnerID
+	now := time.Now()
+	status := DeliveryStatusAssigned
+	order.DeliveryStatus = &status
+	order.RunnerID = &runnerID
+	order.AssignedAt = &now
+	order.UpdatedAt = now
+
+	if err := s.updateDelivery(ctx, order, from, fromRunner); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ConfirmDelivery records that a runner handed an order over at its location. A runner
+// can deliver a waiting order without taking it first.
+func (s *Service) ConfirmDelivery(ctx context.Context, festivalID, orderID, runnerID uuid.UUID) (*Order, error) {
+	order, err := s.getDelivery(ctx, festivalID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	sameRunner := order.RunnerID != nil && *order.RunnerID == runnerID
+	switch *order.DeliveryStatus {
+	case DeliveryStatusDelivered:
+		if sameRunner {
+			return order, nil
+		}
+		return nil, ErrAlreadyDelivered
+	case DeliveryStatusAssigned:
+		if !sameRunner {
+			return nil, ErrDeliveryTaken
+		}
+	}
+
+	from, fromRunner := *order.DeliveryStatus, order.RunnerID
+	now := time.Now()
+	status := DeliveryStatusDelivered
+	order.DeliveryStatus = &status
+	order.RunnerID = &runnerID
+	if order.AssignedAt == nil {
+		order.AssignedAt = &now
+	}
+	order.DeliveredAt = &now
+	order.UpdatedAt = now
+
+	if err := s.updateDelivery(ctx, order, from, fromRunner); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// getDelivery returns a paid order of the festival to be delivered
+func (s *Service) getDelivery(ctx context.Context, festivalID, orderID uuid.UUID) (*Order, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.FestivalID != festivalID {
+		return nil, apperrors.ErrNotFound
+	}
+	if order.DeliveryStatus == nil {
+		return nil, ErrNotForDelivery
+	}
+	if order.Status != OrderStatusPaid {
+		return nil, ErrDeliveryNotPaid
+	}
+	return order, nil
+}
+
+func (s *Service) updateDelivery(ctx context.Context, order *Order, from DeliveryStatus, fromRunner *uuid.UUID) error {
+	updated, err := s.repo.UpdateDelivery(ctx, order, from, fromRunner)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		// Another runner took or delivered the order since it was read
+		return ErrDeliveryTaken
+	}
+	return nil
+}
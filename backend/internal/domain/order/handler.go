@@ -2,10 +2,12 @@ package order
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
 	"github.com/mimi6060/festivals/backend/internal/pkg/response"
 )
@@ -44,7 +46,10 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		orders.POST("", h.CreateOrder)
 		orders.GET("/:id", h.GetOrder)
 		orders.POST("/:id/pay", h.ProcessPayment)
+		orders.POST("/:id/ready", h.MarkReady)
 		orders.POST("/:id/cancel", h.CancelOrder)
+		orders.POST("/:id/void", h.VoidOrder)
+		orders.POST("/:id/correct", h.CorrectOrder)
 		orders.POST("/:id/refund", h.RefundOrder)
 	}
 
@@ -164,7 +169,7 @@ func (h *Handler) GetMyOrder(c *gin.Context) {
 
 // CreateOrder creates a new order
 // @Summary Create order
-// @Description Create a new order for a stand
+// @Description Create a new order for a stand, picked up at the stand or delivered to the table or pitch given as deliveryLocation
 // @Tags orders
 // @Accept json
 // @Produce json
@@ -172,6 +177,7 @@ func (h *Handler) GetMyOrder(c *gin.Context) {
 // @Success 201 {object} response.Response{data=OrderResponse} "Created order"
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Business day closed or stock held by other orders"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /orders [post]
@@ -184,7 +190,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -205,6 +211,22 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 
 	order, err := h.service.CreateOrder(c.Request.Context(), userID, festivalID, walletID, req, staffID)
 	if err != nil {
+		if err == errors.ErrDayClosed {
+			response.Conflict(c, "DAY_CLOSED", "The business day of this stand is closed")
+			return
+		}
+		if err == ErrInvalidDeliveryLocation || err == ErrStandDoesNotDeliver || err == ErrDeliveryUnavailable {
+			response.BadRequest(c, "INVALID_DELIVERY_LOCATION", err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrInvalidCustomField) || err == ErrCustomFieldsUnavailable {
+			response.BadRequest(c, "INVALID_CUSTOM_FIELD", err.Error(), nil)
+			return
+		}
+		if isInsufficientStock(err) {
+			response.Conflict(c, "INSUFFICIENT_STOCK", err.Error())
+			return
+		}
 		response.BadRequest(c, "CREATE_FAILED", err.Error(), nil)
 		return
 	}
@@ -255,6 +277,7 @@ func (h *Handler) GetOrder(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid request or payment failed"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Business day closed"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /orders/{id}/pay [post]
@@ -277,10 +300,18 @@ func (h *Handler) ProcessPayment(c *gin.Context) {
 			response.NotFound(c, "Order not found")
 			return
 		}
+		if err == errors.ErrDayClosed {
+			response.Conflict(c, "DAY_CLOSED", "The business day of this order is closed")
+			return
+		}
 		if err.Error() == "insufficient balance" {
 			response.BadRequest(c, "INSUFFICIENT_BALANCE", "Insufficient wallet balance", nil)
 			return
 		}
+		if errors.Is(err, wallet.ErrWalletFrozen) {
+			response.BadRequest(c, "WALLET_FROZEN", "Wallet is frozen", nil)
+			return
+		}
 		response.BadRequest(c, "PAYMENT_FAILED", err.Error(), nil)
 		return
 	}
@@ -300,6 +331,7 @@ func (h *Handler) ProcessPayment(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Business day closed"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /orders/{id}/cancel [post]
@@ -321,6 +353,10 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 			response.NotFound(c, "Order not found")
 			return
 		}
+		if err == errors.ErrDayClosed {
+			response.Conflict(c, "DAY_CLOSED", "The business day of this order is closed")
+			return
+		}
 		response.BadRequest(c, "CANCEL_FAILED", err.Error(), nil)
 		return
 	}
@@ -328,6 +364,145 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 	response.OK(c, order.ToResponse(h.exchangeRate, h.currencyName))
 }
 
+// VoidOrder voids a pending order entered by mistake
+// @Summary Void order
+// @Description Void a pending order created by mistake, with a reason code (staff only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param request body VoidOrderRequest true "Void reason"
+// @Success 200 {object} response.Response{data=OrderResponse} "Voided order"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or order is not pending"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Business day closed"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/void [post]
+func (h *Handler) VoidOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid order ID", nil)
+		return
+	}
+
+	var req VoidOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	order, err := h.service.VoidOrder(c.Request.Context(), orderID, req, getStaffID(c))
+	if err != nil {
+		if err == errors.ErrNotFound {
+			response.NotFound(c, "Order not found")
+			return
+		}
+		if err == errors.ErrDayClosed {
+			response.Conflict(c, "DAY_CLOSED", "The business day of this order is closed")
+			return
+		}
+		response.BadRequest(c, "VOID_FAILED", err.Error(), nil)
+		return
+	}
+
+	response.OK(c, order.ToResponse(h.exchangeRate, h.currencyName))
+}
+
+// CorrectOrder replaces a paid order with the right items
+// @Summary Correct order
+// @Description Void a paid order rung up with the wrong items and create a linked replacement order; wallet payments are refunded and charged again for the corrected total (staff only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param request body CorrectOrderRequest true "Corrected items and reason"
+// @Success 201 {object} response.Response{data=OrderResponse} "Replacement order"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or correction failed"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Business day closed"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/correct [post]
+func (h *Handler) CorrectOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid order ID", nil)
+		return
+	}
+
+	staffID, err := getStaffIDRequired(c)
+	if err != nil {
+		response.Unauthorized(c, "Staff authentication required")
+		return
+	}
+
+	var req CorrectOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	order, err := h.service.CorrectOrder(c.Request.Context(), orderID, req, staffID)
+	if err != nil {
+		if err == errors.ErrNotFound {
+			response.NotFound(c, "Order not found")
+			return
+		}
+		if err == errors.ErrDayClosed {
+			response.Conflict(c, "DAY_CLOSED", "The business day of this order is closed")
+			return
+		}
+		if strings.Contains(err.Error(), "insufficient balance") {
+			response.BadRequest(c, "INSUFFICIENT_BALANCE", "Insufficient wallet balance", nil)
+			return
+		}
+		if errors.Is(err, wallet.ErrWalletFrozen) {
+			response.BadRequest(c, "WALLET_FROZEN", "Wallet is frozen", nil)
+			return
+		}
+		response.BadRequest(c, "CORRECTION_FAILED", err.Error(), nil)
+		return
+	}
+
+	response.Created(c, order.ToResponse(h.exchangeRate, h.currencyName))
+}
+
+// MarkReady marks a paid order as ready for pickup
+// @Summary Mark order ready
+// @Description Mark a paid order as ready for pickup (staff only); used for stand wait-time estimates
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Success 200 {object} response.Response{data=OrderResponse} "Ready order"
+// @Failure 400 {object} response.ErrorResponse "Order is not paid"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/ready [post]
+func (h *Handler) MarkReady(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid order ID", nil)
+		return
+	}
+
+	order, err := h.service.MarkReady(c.Request.Context(), orderID, getStaffID(c))
+	if err != nil {
+		if err == errors.ErrNotFound {
+			response.NotFound(c, "Order not found")
+			return
+		}
+		response.BadRequest(c, "READY_FAILED", err.Error(), nil)
+		return
+	}
+
+	response.OK(c, order.ToResponse(h.exchangeRate, h.currencyName))
+}
+
 // RefundOrder refunds a paid order
 // @Summary Refund order
 // @Description Refund a paid order (staff only)
@@ -340,6 +515,7 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Order not found"
+// @Failure 409 {object} response.ErrorResponse "Business day closed"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /orders/{id}/refund [post]
@@ -352,7 +528,7 @@ func (h *Handler) RefundOrder(c *gin.Context) {
 
 	var req RefundOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -364,6 +540,10 @@ func (h *Handler) RefundOrder(c *gin.Context) {
 			response.NotFound(c, "Order not found")
 			return
 		}
+		if err == errors.ErrDayClosed {
+			response.Conflict(c, "DAY_CLOSED", "The business day of this order is closed")
+			return
+		}
 		response.BadRequest(c, "REFUND_FAILED", err.Error(), nil)
 		return
 	}
@@ -379,9 +559,10 @@ func (h *Handler) RefundOrder(c *gin.Context) {
 // @Param standId path string true "Stand ID" format(uuid)
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(20)
-// @Param status query string false "Filter by status" Enums(PENDING, PAID, CANCELLED, REFUNDED)
+// @Param status query string false "Filter by status" Enums(PENDING, PAID, CANCELLED, REFUNDED, VOIDED)
 // @Param start_date query string false "Filter by start date" format(date-time)
 // @Param end_date query string false "Filter by end date" format(date-time)
+// @Param field[key] query string false "Filter by the value of a custom field, e.g. field[table]=12"
 // @Success 200 {object} response.Response{data=[]OrderResponse,meta=response.Meta} "Order list"
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
@@ -468,9 +649,10 @@ func (h *Handler) GetStandStats(c *gin.Context) {
 // @Param festivalId path string true "Festival ID" format(uuid)
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(20)
-// @Param status query string false "Filter by status" Enums(PENDING, PAID, CANCELLED, REFUNDED)
+// @Param status query string false "Filter by status" Enums(PENDING, PAID, CANCELLED, REFUNDED, VOIDED)
 // @Param start_date query string false "Filter by start date" format(date-time)
 // @Param end_date query string false "Filter by end date" format(date-time)
+// @Param field[key] query string false "Filter by the value of a custom field, e.g. field[table]=12"
 // @Success 200 {object} response.Response{data=[]OrderResponse,meta=response.Meta} "Order list"
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
@@ -540,6 +722,11 @@ func (h *Handler) buildFilter(c *gin.Context) *OrderFilter {
 		}
 	}
 
+	if fields := c.QueryMap("field"); len(fields) > 0 {
+		filter.CustomFields = fields
+		hasFilter = true
+	}
+
 	if !hasFilter {
 		return nil
 	}
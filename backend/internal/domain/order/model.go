@@ -7,24 +7,41 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
 )
 
 // Order represents a purchase order at a stand
 type Order struct {
-	ID            uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	FestivalID    uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
-	UserID        uuid.UUID   `json:"userId" gorm:"type:uuid;not null;index"`
-	WalletID      uuid.UUID   `json:"walletId" gorm:"type:uuid;not null;index"`
-	StandID       uuid.UUID   `json:"standId" gorm:"type:uuid;not null;index"`
-	Items         OrderItems  `json:"items" gorm:"type:jsonb;not null"`
-	TotalAmount   int64       `json:"totalAmount" gorm:"not null"` // Total amount in cents
-	Status        OrderStatus `json:"status" gorm:"default:'PENDING'"`
-	PaymentMethod string      `json:"paymentMethod" gorm:"not null"` // wallet, cash, card
-	TransactionID *uuid.UUID  `json:"transactionId,omitempty" gorm:"type:uuid"` // Linked wallet transaction
-	StaffID       *uuid.UUID  `json:"staffId,omitempty" gorm:"type:uuid"` // Staff who processed the order
-	Notes         string      `json:"notes,omitempty"`
-	CreatedAt     time.Time   `json:"createdAt"`
-	UpdatedAt     time.Time   `json:"updatedAt"`
+	ID                 uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID         uuid.UUID         `json:"festivalId" gorm:"type:uuid;not null;index"`
+	UserID             uuid.UUID         `json:"userId" gorm:"type:uuid;not null;index"`
+	WalletID           uuid.UUID         `json:"walletId" gorm:"type:uuid;not null;index"`
+	StandID            uuid.UUID         `json:"standId" gorm:"type:uuid;not null;index"`
+	Items              OrderItems        `json:"items" gorm:"type:jsonb;not null"`
+	TotalAmount        int64             `json:"totalAmount" gorm:"not null"` // Total amount in cents
+	Status             OrderStatus       `json:"status" gorm:"default:'PENDING'"`
+	PaymentMethod      string            `json:"paymentMethod" gorm:"not null"`            // wallet, cash, card
+	TransactionID      *uuid.UUID        `json:"transactionId,omitempty" gorm:"type:uuid"` // Linked wallet transaction
+	StaffID            *uuid.UUID        `json:"staffId,omitempty" gorm:"type:uuid"`       // Staff who processed the order
+	PriceListID        *uuid.UUID        `json:"priceListId,omitempty" gorm:"type:uuid"`   // Price list in effect when the order was created
+	Notes              string            `json:"notes,omitempty"`
+	ReadyAt            *time.Time        `json:"readyAt,omitempty"` // When the stand marked the order ready for pickup
+	VoidReason         *VoidReason       `json:"voidReason,omitempty"`
+	VoidedAt           *time.Time        `json:"voidedAt,omitempty"`
+	ReplacesID         *uuid.UUID        `json:"replacesId,omitempty" gorm:"column:replaces_order_id;type:uuid"`      // Voided order this order corrects
+	ReplacedByID       *uuid.UUID        `json:"replacedById,omitempty" gorm:"column:replaced_by_order_id;type:uuid"` // Correction that replaced this voided order
+	DeliveryLocationID *uuid.UUID        `json:"deliveryLocationId,omitempty" gorm:"type:uuid"`                       // Table or pitch the order is brought to
+	DeliveryLocation   string            `json:"deliveryLocation,omitempty" gorm:"column:delivery_location_label"`    // Label of the location when ordered
+	DeliveryStatus     *DeliveryStatus   `json:"deliveryStatus,omitempty"`                                            // Nil for pickup orders
+	RunnerID           *uuid.UUID        `json:"runnerId,omitempty" gorm:"type:uuid"`                                 // Runner delivering the order
+	DeliveryDueAt      *time.Time        `json:"deliveryDueAt,omitempty"`                                             // Deadline set by the SLA of the location
+	AssignedAt         *time.Time        `json:"assignedAt,omitempty"`
+	DeliveredAt        *time.Time        `json:"deliveredAt,omitempty"`
+	AutoCancelledAt    *time.Time        `json:"autoCancelledAt,omitempty"`                         // When the order was cancelled for not being paid in time
+	CustomFields       CustomFieldValues `json:"customFields,omitempty" gorm:"type:jsonb;not null"` // Values of the custom fields of the festival
+	RoundUp            *wallet.RoundUp   `json:"roundUp,omitempty" gorm:"-"`                        // Donation the wallet payment was rounded up by, for the receipt
+	CreatedAt          time.Time         `json:"createdAt"`
+	UpdatedAt          time.Time         `json:"updatedAt"`
 }
 
 func (Order) TableName() string {
@@ -36,8 +53,10 @@ type OrderItem struct {
 	ProductID   uuid.UUID `json:"productId"`
 	ProductName string    `json:"productName"`
 	Quantity    int       `json:"quantity"`
-	UnitPrice   int64     `json:"unitPrice"`   // Price per unit in cents
-	TotalPrice  int64     `json:"totalPrice"`  // Total price for this item (quantity * unitPrice)
+	UnitPrice   int64     `json:"unitPrice"`           // Price per unit in cents
+	TotalPrice  int64     `json:"totalPrice"`          // Total price for this item (quantity * unitPrice)
+	UnitCost    *int64    `json:"unitCost,omitempty"`  // Cost price of the product when ordered, nil when unknown
+	Allergens   []string  `json:"allergens,omitempty"` // Allergens declared on the product when ordered
 }
 
 // OrderItems is a slice of OrderItem that implements GORM's Scanner and Valuer interfaces
@@ -72,6 +91,28 @@ const (
 	OrderStatusPaid      OrderStatus = "PAID"
 	OrderStatusCancelled OrderStatus = "CANCELLED"
 	OrderStatusRefunded  OrderStatus = "REFUNDED"
+	OrderStatusVoided    OrderStatus = "VOIDED" // Entered by mistake; never counted as a sale
+)
+
+// VoidReason explains why a staff member voided an order
+type VoidReason string
+
+const (
+	VoidReasonWrongItem     VoidReason = "WRONG_ITEM"
+	VoidReasonWrongQuantity VoidReason = "WRONG_QUANTITY"
+	VoidReasonDuplicate     VoidReason = "DUPLICATE"
+	VoidReasonCustomerLeft  VoidReason = "CUSTOMER_LEFT"
+	VoidReasonTestOrder     VoidReason = "TEST_ORDER"
+	VoidReasonOther         VoidReason = "OTHER"
+)
+
+// DeliveryStatus tracks an order brought to a table or pitch
+type DeliveryStatus string
+
+const (
+	DeliveryStatusWaiting   DeliveryStatus = "WAITING"   // In the queue, no runner yet
+	DeliveryStatusAssigned  DeliveryStatus = "ASSIGNED"  // Taken by a runner
+	DeliveryStatusDelivered DeliveryStatus = "DELIVERED" // Confirmed delivered by the runner
 )
 
 // PaymentMethod constants
@@ -87,6 +128,11 @@ type CreateOrderRequest struct {
 	Items         []OrderItemRequest `json:"items" binding:"required,min=1"`
 	PaymentMethod string             `json:"paymentMethod" binding:"required,oneof=wallet cash card"`
 	Notes         string             `json:"notes,omitempty"`
+	// Table or pitch to deliver to: the scanned QR code or the printed location code.
+	// Orders without one are picked up at the stand.
+	DeliveryLocation string `json:"deliveryLocation,omitempty" binding:"max=512"`
+	// Values of the custom fields of the festival by key, e.g. {"table": "12"}
+	CustomFields map[string]string `json:"customFields,omitempty"`
 }
 
 // OrderItemRequest represents an item in a create order request
@@ -107,6 +153,19 @@ type CancelOrderRequest struct {
 	Reason string `json:"reason,omitempty"`
 }
 
+// VoidOrderRequest represents the request to void an order entered by mistake
+type VoidOrderRequest struct {
+	Reason VoidReason `json:"reason" binding:"required,oneof=WRONG_ITEM WRONG_QUANTITY DUPLICATE CUSTOMER_LEFT TEST_ORDER OTHER"`
+	Note   string     `json:"note,omitempty"`
+}
+
+// CorrectOrderRequest represents the request to replace a paid order with the right items
+type CorrectOrderRequest struct {
+	Reason VoidReason         `json:"reason" binding:"required,oneof=WRONG_ITEM WRONG_QUANTITY DUPLICATE OTHER"`
+	Items  []OrderItemRequest `json:"items" binding:"required,min=1"`
+	Note   string             `json:"note,omitempty"`
+}
+
 // RefundOrderRequest represents the request to refund an order
 type RefundOrderRequest struct {
 	Reason string `json:"reason" binding:"required"`
@@ -124,21 +183,36 @@ type OrderListRequest struct {
 
 // OrderResponse represents the API response for an order
 type OrderResponse struct {
-	ID              uuid.UUID           `json:"id"`
-	FestivalID      uuid.UUID           `json:"festivalId"`
-	UserID          uuid.UUID           `json:"userId"`
-	WalletID        uuid.UUID           `json:"walletId"`
-	StandID         uuid.UUID           `json:"standId"`
-	Items           []OrderItemResponse `json:"items"`
-	TotalAmount     int64               `json:"totalAmount"`
-	TotalDisplay    string              `json:"totalDisplay"`
-	Status          OrderStatus         `json:"status"`
-	PaymentMethod   string              `json:"paymentMethod"`
-	TransactionID   *uuid.UUID          `json:"transactionId,omitempty"`
-	StaffID         *uuid.UUID          `json:"staffId,omitempty"`
-	Notes           string              `json:"notes,omitempty"`
-	CreatedAt       string              `json:"createdAt"`
-	UpdatedAt       string              `json:"updatedAt"`
+	ID                 uuid.UUID           `json:"id"`
+	FestivalID         uuid.UUID           `json:"festivalId"`
+	UserID             uuid.UUID           `json:"userId"`
+	WalletID           uuid.UUID           `json:"walletId"`
+	StandID            uuid.UUID           `json:"standId"`
+	Items              []OrderItemResponse `json:"items"`
+	TotalAmount        int64               `json:"totalAmount"`
+	TotalDisplay       string              `json:"totalDisplay"`
+	Status             OrderStatus         `json:"status"`
+	PaymentMethod      string              `json:"paymentMethod"`
+	TransactionID      *uuid.UUID          `json:"transactionId,omitempty"`
+	StaffID            *uuid.UUID          `json:"staffId,omitempty"`
+	PriceListID        *uuid.UUID          `json:"priceListId,omitempty"`
+	Notes              string              `json:"notes,omitempty"`
+	ReadyAt            *string             `json:"readyAt,omitempty"`
+	VoidReason         *VoidReason         `json:"voidReason,omitempty"`
+	VoidedAt           *string             `json:"voidedAt,omitempty"`
+	ReplacesID         *uuid.UUID          `json:"replacesId,omitempty"`
+	ReplacedByID       *uuid.UUID          `json:"replacedById,omitempty"`
+	DeliveryLocationID *uuid.UUID          `json:"deliveryLocationId,omitempty"`
+	DeliveryLocation   string              `json:"deliveryLocation,omitempty"`
+	DeliveryStatus     *DeliveryStatus     `json:"deliveryStatus,omitempty"`
+	RunnerID           *uuid.UUID          `json:"runnerId,omitempty"`
+	DeliveryDueAt      *string             `json:"deliveryDueAt,omitempty"`
+	AssignedAt         *string             `json:"assignedAt,omitempty"`
+	DeliveredAt        *string             `json:"deliveredAt,omitempty"`
+	CustomFields       CustomFieldValues   `json:"customFields,omitempty"`
+	RoundUp            *wallet.RoundUp     `json:"roundUp,omitempty"`
+	CreatedAt          string              `json:"createdAt"`
+	UpdatedAt          string              `json:"updatedAt"`
 }
 
 // OrderItemResponse represents an item in an order response
@@ -150,6 +224,7 @@ type OrderItemResponse struct {
 	UnitDisplay  string    `json:"unitDisplay"`
 	TotalPrice   int64     `json:"totalPrice"`
 	TotalDisplay string    `json:"totalDisplay"`
+	Allergens    []string  `json:"allergens,omitempty"`
 }
 
 func (o *Order) ToResponse(exchangeRate float64, currencyName string) OrderResponse {
@@ -163,25 +238,41 @@ func (o *Order) ToResponse(exchangeRate float64, currencyName string) OrderRespo
 			UnitDisplay:  formatPrice(float64(item.UnitPrice)*exchangeRate, currencyName),
 			TotalPrice:   item.TotalPrice,
 			TotalDisplay: formatPrice(float64(item.TotalPrice)*exchangeRate, currencyName),
+			Allergens:    item.Allergens,
 		}
 	}
 
 	return OrderResponse{
-		ID:            o.ID,
-		FestivalID:    o.FestivalID,
-		UserID:        o.UserID,
-		WalletID:      o.WalletID,
-		StandID:       o.StandID,
-		Items:         items,
-		TotalAmount:   o.TotalAmount,
-		TotalDisplay:  formatPrice(float64(o.TotalAmount)*exchangeRate, currencyName),
-		Status:        o.Status,
-		PaymentMethod: o.PaymentMethod,
-		TransactionID: o.TransactionID,
-		StaffID:       o.StaffID,
-		Notes:         o.Notes,
-		CreatedAt:     o.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     o.UpdatedAt.Format(time.RFC3339),
+		ID:                 o.ID,
+		FestivalID:         o.FestivalID,
+		UserID:             o.UserID,
+		WalletID:           o.WalletID,
+		StandID:            o.StandID,
+		Items:              items,
+		TotalAmount:        o.TotalAmount,
+		TotalDisplay:       formatPrice(float64(o.TotalAmount)*exchangeRate, currencyName),
+		Status:             o.Status,
+		PaymentMethod:      o.PaymentMethod,
+		TransactionID:      o.TransactionID,
+		StaffID:            o.StaffID,
+		PriceListID:        o.PriceListID,
+		Notes:              o.Notes,
+		ReadyAt:            formatOptionalTime(o.ReadyAt),
+		VoidReason:         o.VoidReason,
+		VoidedAt:           formatOptionalTime(o.VoidedAt),
+		ReplacesID:         o.ReplacesID,
+		ReplacedByID:       o.ReplacedByID,
+		DeliveryLocationID: o.DeliveryLocationID,
+		DeliveryLocation:   o.DeliveryLocation,
+		DeliveryStatus:     o.DeliveryStatus,
+		RunnerID:           o.RunnerID,
+		DeliveryDueAt:      formatOptionalTime(o.DeliveryDueAt),
+		AssignedAt:         formatOptionalTime(o.AssignedAt),
+		DeliveredAt:        formatOptionalTime(o.DeliveredAt),
+		CustomFields:       o.CustomFields,
+		RoundUp:            o.RoundUp,
+		CreatedAt:          o.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          o.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -194,6 +285,16 @@ type OrderStandStats struct {
 	PaidOrders    int64     `json:"paidOrders"`
 	CancelledOrders int64   `json:"cancelledOrders"`
 	RefundedOrders  int64   `json:"refundedOrders"`
+	VoidedOrders    int64   `json:"voidedOrders"`
+	VoidedAmount    int64   `json:"voidedAmount"` // Total of voided orders in cents
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
 }
 
 func formatPrice(tokens float64, currencyName string) string {
@@ -2,10 +2,13 @@ package order
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
 	"gorm.io/gorm"
 )
 
@@ -15,6 +18,7 @@ type Repository interface {
 	GetOrderByID(ctx context.Context, id uuid.UUID) (*Order, error)
 	UpdateOrder(ctx context.Context, order *Order) error
 	DeleteOrder(ctx context.Context, id uuid.UUID) error
+	ReplaceOrder(ctx context.Context, voided *Order, replacement *Order) error
 
 	// Query operations
 	GetOrdersByUser(ctx context.Context, userID, festivalID uuid.UUID, offset, limit int) ([]Order, int64, error)
@@ -23,6 +27,18 @@ type Repository interface {
 
 	// Statistics
 	GetStandStats(ctx context.Context, standID uuid.UUID, startDate, endDate *time.Time) (*OrderStandStats, error)
+
+	// Wait times
+	GetStandThroughput(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]StandThroughput, error)
+	GetFestivalsWithOpenOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+
+	// Deliveries
+	GetDeliveryQueue(ctx context.Context, festivalID uuid.UUID, filter DeliveryQueueFilter) ([]Order, error)
+	UpdateDelivery(ctx context.Context, order *Order, from DeliveryStatus, fromRunner *uuid.UUID) (bool, error)
+
+	// Auto-cancellation
+	CancelStaleOrders(ctx context.Context, now time.Time, scope clock.Scope, limit int) (int64, error)
+	GetAutoCancelStats(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]StandAutoCancelStats, error)
 }
 
 // OrderFilter represents filter options for querying orders
@@ -31,6 +47,8 @@ type OrderFilter struct {
 	StartDate *time.Time
 	EndDate   *time.Time
 	UserID    *uuid.UUID
+	// Values of custom fields the orders must have, by key
+	CustomFields map[string]string
 }
 
 type repository struct {
@@ -65,6 +83,19 @@ func (r *repository) DeleteOrder(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&Order{}, "id = ?", id).Error
 }
 
+// ReplaceOrder saves a voided order and creates its replacement in one transaction
+func (r *repository) ReplaceOrder(ctx context.Context, voided *Order, replacement *Order) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return fmt.Errorf("failed to create replacement order: %w", err)
+		}
+		if err := tx.Save(voided).Error; err != nil {
+			return fmt.Errorf("failed to void order: %w", err)
+		}
+		return nil
+	})
+}
+
 func (r *repository) GetOrdersByUser(ctx context.Context, userID, festivalID uuid.UUID, offset, limit int) ([]Order, int64, error) {
 	var orders []Order
 	var total int64
@@ -140,6 +171,11 @@ func (r *repository) applyFilter(query *gorm.DB, filter *OrderFilter) *gorm.DB {
 		query = query.Where("user_id = ?", *filter.UserID)
 	}
 
+	for key, value := range filter.CustomFields {
+		match, _ := json.Marshal([]map[string]string{{"key": key, "value": value}})
+		query = query.Where("custom_fields @> ?::jsonb", string(match))
+	}
+
 	return query
 }
 
@@ -198,8 +234,196 @@ func (r *repository) GetStandStats(ctx context.Context, standID uuid.UUID, start
 			stats.CancelledOrders = sc.Count
 		case OrderStatusRefunded:
 			stats.RefundedOrders = sc.Count
+		case OrderStatusVoided:
+			stats.VoidedOrders = sc.Count
 		}
 	}
 
+	// Total voided (only from voided orders)
+	var voidedAmount struct {
+		Sum int64
+	}
+	if err := r.db.WithContext(ctx).Model(&Order{}).
+		Select("COALESCE(SUM(total_amount), 0) as sum").
+		Where("stand_id = ? AND status = ?", standID, OrderStatusVoided).
+		Scan(&voidedAmount).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate voided amount: %w", err)
+	}
+	stats.VoidedAmount = voidedAmount.Sum
+
 	return stats, nil
 }
+
+// GetStandThroughput aggregates, per stand, the orders made ready since the given
+// time and the paid orders still waiting to be made ready
+func (r *repository) GetStandThroughput(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]StandThroughput, error) {
+	var results []StandThroughput
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.stand_id,
+			COALESCE(s.name, '') as stand_name,
+			COUNT(*) FILTER (WHERE o.ready_at >= @since) as completed_orders,
+			COALESCE(AVG(EXTRACT(EPOCH FROM (o.ready_at - o.created_at))) FILTER (WHERE o.ready_at >= @since), 0) as avg_prep_seconds,
+			COUNT(*) FILTER (WHERE o.ready_at IS NULL) as open_orders
+		FROM orders o
+		LEFT JOIN stands s ON s.id = o.stand_id
+		WHERE o.festival_id = @festival
+			AND o.status = @paid
+			AND (o.ready_at >= @since OR (o.ready_at IS NULL AND o.created_at >= @openSince))
+		GROUP BY o.stand_id, s.name`,
+		map[string]interface{}{
+			"festival":  festivalID,
+			"paid":      OrderStatusPaid,
+			"since":     since,
+			"openSince": since.Add(-openOrderMaxAge),
+		}).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand throughput: %w", err)
+	}
+	return results, nil
+}
+
+// GetFestivalsWithOpenOrders returns the festivals with order activity since the given time
+func (r *repository) GetFestivalsWithOpenOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&Order{}).
+		Distinct("festival_id").
+		Where("status = ? AND (ready_at >= ? OR (ready_at IS NULL AND created_at >= ?))", OrderStatusPaid, since, since.Add(-openOrderMaxAge)).
+		Pluck("festival_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festivals with open orders: %w", err)
+	}
+	return ids, nil
+}
+
+// GetDeliveryQueue returns the paid orders of a festival waiting for delivery or being
+// delivered, the closest deadline first
+func (r *repository) GetDeliveryQueue(ctx context.Context, festivalID uuid.UUID, filter DeliveryQueueFilter) ([]Order, error) {
+	query := r.db.WithContext(ctx).
+		Where("festival_id = ? AND status = ?", festivalID, OrderStatusPaid).
+		Where("delivery_status IN ?", []DeliveryStatus{DeliveryStatusWaiting, DeliveryStatusAssigned})
+
+	if filter.StandID != nil {
+		query = query.Where("stand_id = ?", *filter.StandID)
+	}
+	if filter.RunnerID != nil {
+		query = query.Where("runner_id = ?", *filter.RunnerID)
+	}
+	if filter.Status != nil {
+		query = query.Where("delivery_status = ?", *filter.Status)
+	}
+
+	var orders []Order
+	if err := query.Order("delivery_due_at ASC, created_at ASC").Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to get delivery queue: %w", err)
+	}
+	return orders, nil
+}
+
+// UpdateDelivery saves the delivery of an order if it is still in the given status and
+// with the given runner, so that two runners cannot take the same order. It reports
+// whether the order was updated.
+func (r *repository) UpdateDelivery(ctx context.Context, order *Order, from DeliveryStatus, fromRunner *uuid.UUID) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&Order{}).
+		Where("id = ? AND status = ? AND delivery_status = ?", order.ID, OrderStatusPaid, from)
+	if fromRunner != nil {
+		query = query.Where("runner_id = ?", *fromRunner)
+	} else {
+		query = query.Where("runner_id IS NULL")
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"delivery_status": order.DeliveryStatus,
+		"runner_id":       order.RunnerID,
+		"assigned_at":     order.AssignedAt,
+		"delivered_at":    order.DeliveredAt,
+		"updated_at":      order.UpdatedAt,
+	})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update delivery: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CancelStaleOrders cancels at most limit pending orders older than the
+// pendingOrderTtlMinutes setting of their festival, oldest first. Festivals without
+// the setting are skipped, as are orders of closed business days. The stock held for
+// the orders is released in the same statement. It returns the number of orders
+// cancelled among the festivals of the scope.
+func (r *repository) CancelStaleOrders(ctx context.Context, now time.Time, scope clock.Scope, limit int) (int64, error) {
+	params := map[string]interface{}{
+		"cancelled": OrderStatusCancelled,
+		"pending":   OrderStatusPending,
+		"released":  product.ReservationStatusReleased,
+		"held":      product.ReservationStatusHeld,
+		"now":       now,
+		"limit":     limit,
+	}
+	var filter string
+	if scope.FestivalID != nil {
+		filter += " AND o.festival_id = @festival"
+		params["festival"] = *scope.FestivalID
+	}
+	if len(scope.Exclude) > 0 {
+		filter += " AND o.festival_id NOT IN @exclude"
+		params["exclude"] = scope.Exclude
+	}
+
+	var cancelled int64
+	err := r.db.WithContext(ctx).Raw(`
+		WITH cancelled AS (
+			UPDATE orders
+			SET status = @cancelled, auto_cancelled_at = @now, updated_at = @now
+			WHERE status = @pending AND id IN (
+				SELECT o.id
+				FROM orders o
+				INNER JOIN festivals f ON f.id = o.festival_id
+				WHERE o.status = @pending`+filter+`
+					AND COALESCE(CAST(f.settings->>'pendingOrderTtlMinutes' AS INTEGER), 0) > 0
+					AND o.created_at < CAST(@now AS timestamptz) - make_interval(mins => CAST(f.settings->>'pendingOrderTtlMinutes' AS INTEGER))
+					AND NOT EXISTS (
+						SELECT 1 FROM day_closes dc
+						WHERE dc.festival_id = o.festival_id AND (dc.stand_id IS NULL OR dc.stand_id = o.stand_id)
+							AND dc.period_start <= o.created_at AND dc.period_end > o.created_at
+					)
+				ORDER BY o.created_at
+				LIMIT @limit
+				FOR UPDATE OF o SKIP LOCKED
+			)
+			RETURNING id
+		), released AS (
+			UPDATE stock_reservations
+			SET status = @released, updated_at = @now
+			WHERE order_id IN (SELECT id FROM cancelled) AND status = @held
+		)
+		SELECT COUNT(*) FROM cancelled`,
+		params).Scan(&cancelled).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel stale orders: %w", err)
+	}
+	return cancelled, nil
+}
+
+// GetAutoCancelStats counts, per stand, the orders created in [from, to) and the ones
+// cancelled for not being paid in time
+func (r *repository) GetAutoCancelStats(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]StandAutoCancelStats, error) {
+	var results []StandAutoCancelStats
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.stand_id,
+			COALESCE(s.name, '') as stand_name,
+			COUNT(*) as orders,
+			COUNT(*) FILTER (WHERE o.auto_cancelled_at IS NOT NULL) as auto_cancelled,
+			COALESCE(SUM(o.total_amount) FILTER (WHERE o.auto_cancelled_at IS NOT NULL), 0) as auto_cancelled_amount
+		FROM orders o
+		LEFT JOIN stands s ON s.id = o.stand_id
+		WHERE o.festival_id = ? AND o.created_at >= ? AND o.created_at < ?
+		GROUP BY o.stand_id, s.name
+		ORDER BY auto_cancelled DESC, s.name`,
+		festivalID, from, to,
+	).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto-cancel stats: %w", err)
+	}
+	return results, nil
+}
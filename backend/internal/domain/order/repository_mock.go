@@ -0,0 +1,103 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateOrder(ctx context.Context, order *Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetOrderByID(ctx context.Context, id uuid.UUID) (*Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Order), args.Error(1)
+}
+
+func (m *MockRepository) UpdateOrder(ctx context.Context, order *Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteOrder(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReplaceOrder(ctx context.Context, voided *Order, replacement *Order) error {
+	args := m.Called(ctx, voided, replacement)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetOrdersByUser(ctx context.Context, userID, festivalID uuid.UUID, offset, limit int) ([]Order, int64, error) {
+	args := m.Called(ctx, userID, festivalID, offset, limit)
+	return args.Get(0).([]Order), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetOrdersByStand(ctx context.Context, standID uuid.UUID, offset, limit int, filter *OrderFilter) ([]Order, int64, error) {
+	args := m.Called(ctx, standID, offset, limit, filter)
+	return args.Get(0).([]Order), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetOrdersByFestival(ctx context.Context, festivalID uuid.UUID, offset, limit int, filter *OrderFilter) ([]Order, int64, error) {
+	args := m.Called(ctx, festivalID, offset, limit, filter)
+	return args.Get(0).([]Order), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetStandStats(ctx context.Context, standID uuid.UUID, startDate, endDate *time.Time) (*OrderStandStats, error) {
+	args := m.Called(ctx, standID, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OrderStandStats), args.Error(1)
+}
+
+func (m *MockRepository) GetStandThroughput(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]StandThroughput, error) {
+	args := m.Called(ctx, festivalID, since)
+	return args.Get(0).([]StandThroughput), args.Error(1)
+}
+
+func (m *MockRepository) GetFestivalsWithOpenOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetDeliveryQueue(ctx context.Context, festivalID uuid.UUID, filter DeliveryQueueFilter) ([]Order, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Order), args.Error(1)
+}
+
+func (m *MockRepository) UpdateDelivery(ctx context.Context, order *Order, from DeliveryStatus, fromRunner *uuid.UUID) (bool, error) {
+	args := m.Called(ctx, order, from, fromRunner)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CancelStaleOrders(ctx context.Context, now time.Time, scope clock.Scope, limit int) (int64, error) {
+	args := m.Called(ctx, now, scope, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) GetAutoCancelStats(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]StandAutoCancelStats, error) {
+	args := m.Called(ctx, festivalID, from, to)
+	return args.Get(0).([]StandAutoCancelStats), args.Error(1)
+}
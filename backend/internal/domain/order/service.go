@@ -11,10 +11,48 @@ import (
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
 )
 
+// PriceListResolver returns the price list in effect at a stand, or nil for regular prices
+type PriceListResolver interface {
+	ActivePriceList(ctx context.Context, festivalID, standID uuid.UUID) (*product.PriceList, error)
+}
+
+// OrderObserver follows the order rate of festivals; satisfied by activity.Service
+type OrderObserver interface {
+	ObserveOrder(ctx context.Context, festivalID uuid.UUID, at time.Time)
+}
+
+// OrderPrinter queues the tickets of paid orders on the stand printers; satisfied by
+// printing.Service
+type OrderPrinter interface {
+	PrintOrder(ctx context.Context, order *Order) error
+}
+
+// ClosedDayChecker reports whether the business day of a stand at a time was closed;
+// satisfied by dayclose.Service
+type ClosedDayChecker interface {
+	IsDayClosed(ctx context.Context, festivalID, standID uuid.UUID, at time.Time) (bool, error)
+}
+
+type closedDayAdjustmentKey struct{}
+
+// WithClosedDayAdjustment marks ctx as an audited day close adjustment, allowed to
+// change the orders of closed business days
+func WithClosedDayAdjustment(ctx context.Context) context.Context {
+	return context.WithValue(ctx, closedDayAdjustmentKey{}, true)
+}
+
 type Service struct {
 	repo          Repository
 	productRepo   product.Repository
 	walletService *wallet.Service
+	priceLists    PriceListResolver
+	observer      OrderObserver
+	printer       OrderPrinter
+	closedDays    ClosedDayChecker
+	deliveries    DeliveryLocationResolver
+	reserver      StockReserver
+	customFields  CustomFieldValidator
+	stockHoldTTL  time.Duration
 }
 
 func NewService(repo Repository, productRepo product.Repository, walletService *wallet.Service) *Service {
@@ -25,62 +63,35 @@ func NewService(repo Repository, productRepo product.Repository, walletService *
 	}
 }
 
-// CreateOrder creates a new order from cart items
-func (s *Service) CreateOrder(ctx context.Context, userID, festivalID, walletID uuid.UUID, req CreateOrderRequest, staffID *uuid.UUID) (*Order, error) {
-	// Collect all product IDs for batch fetch (avoids N+1 queries)
-	productIDs := make([]uuid.UUID, len(req.Items))
-	quantityMap := make(map[uuid.UUID]int, len(req.Items))
-	for i, itemReq := range req.Items {
-		productIDs[i] = itemReq.ProductID
-		quantityMap[itemReq.ProductID] = itemReq.Quantity
-	}
-
-	// Fetch all products in a single query
-	products, err := s.productRepo.GetByIDs(ctx, productIDs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get products: %w", err)
-	}
-
-	// Create product map for quick lookup
-	productMap := make(map[uuid.UUID]*product.Product, len(products))
-	for i := range products {
-		productMap[products[i].ID] = &products[i]
-	}
-
-	// Validate and build order items
-	items := make([]OrderItem, 0, len(req.Items))
-	var totalAmount int64
+// SetPriceListResolver enables scheduled price lists when pricing orders
+func (s *Service) SetPriceListResolver(resolver PriceListResolver) {
+	s.priceLists = resolver
+}
 
-	for _, itemReq := range req.Items {
-		prod, exists := productMap[itemReq.ProductID]
-		if !exists {
-			return nil, fmt.Errorf("product %s not found", itemReq.ProductID)
-		}
+// SetOrderObserver reports the orders created to the activity feed
+func (s *Service) SetOrderObserver(observer OrderObserver) {
+	s.observer = observer
+}
 
-		// Verify product belongs to the stand
-		if prod.StandID != req.StandID {
-			return nil, fmt.Errorf("product %s does not belong to stand %s", itemReq.ProductID, req.StandID)
-		}
+// SetOrderPrinter prints the tickets of paid orders at their stand
+func (s *Service) SetOrderPrinter(printer OrderPrinter) {
+	s.printer = printer
+}
 
-		// Check product availability
-		if prod.Status != product.ProductStatusActive {
-			return nil, fmt.Errorf("product %s is not available", prod.Name)
-		}
+// SetClosedDayChecker freezes the orders of closed business days
+func (s *Service) SetClosedDayChecker(checker ClosedDayChecker) {
+	s.closedDays = checker
+}
 
-		// Check stock if applicable
-		if prod.Stock != nil && *prod.Stock < itemReq.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s", prod.Name)
-		}
+// CreateOrder creates a new order from cart items
+func (s *Service) CreateOrder(ctx context.Context, userID, festivalID, walletID uuid.UUID, req CreateOrderRequest, staffID *uuid.UUID) (*Order, error) {
+	if err := s.checkDayOpen(ctx, festivalID, req.StandID, time.Now()); err != nil {
+		return nil, err
+	}
 
-		itemTotal := prod.Price * int64(itemReq.Quantity)
-		items = append(items, OrderItem{
-			ProductID:   prod.ID,
-			ProductName: prod.Name,
-			Quantity:    itemReq.Quantity,
-			UnitPrice:   prod.Price,
-			TotalPrice:  itemTotal,
-		})
-		totalAmount += itemTotal
+	items, totalAmount, priceListID, err := s.buildOrderItems(ctx, festivalID, req.StandID, req.Items)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create order
@@ -95,15 +106,35 @@ func (s *Service) CreateOrder(ctx context.Context, userID, festivalID, walletID
 		Status:        OrderStatusPending,
 		PaymentMethod: req.PaymentMethod,
 		StaffID:       staffID,
+		PriceListID:   priceListID,
 		Notes:         req.Notes,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
 
+	if req.DeliveryLocation != "" {
+		if err := s.applyDelivery(ctx, order, req.DeliveryLocation); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyCustomFields(ctx, order, req.CustomFields); err != nil {
+		return nil, err
+	}
+
+	if err := s.reserveStock(ctx, order); err != nil {
+		return nil, err
+	}
+
 	if err := s.repo.CreateOrder(ctx, order); err != nil {
+		s.releaseStock(ctx, order)
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
+	if s.observer != nil {
+		s.observer.ObserveOrder(ctx, festivalID, order.CreatedAt)
+	}
+
 	return order, nil
 }
 
@@ -120,6 +151,9 @@ func (s *Service) ProcessPayment(ctx context.Context, orderID uuid.UUID, staffID
 	if order.Status != OrderStatusPending {
 		return nil, fmt.Errorf("order is not in pending status")
 	}
+	if err := s.checkDayOpen(ctx, order.FestivalID, order.StandID, order.CreatedAt); err != nil {
+		return nil, err
+	}
 
 	// Process payment based on payment method
 	switch order.PaymentMethod {
@@ -135,6 +169,7 @@ func (s *Service) ProcessPayment(ctx context.Context, orderID uuid.UUID, staffID
 			return nil, fmt.Errorf("payment failed: %w", err)
 		}
 		order.TransactionID = &tx.ID
+		order.RoundUp = tx.RoundUp
 
 	case PaymentMethodCash, PaymentMethodCard:
 		// For cash/card payments, we just mark the order as paid
@@ -154,12 +189,46 @@ func (s *Service) ProcessPayment(ctx context.Context, orderID uuid.UUID, staffID
 		return nil, fmt.Errorf("failed to update order: %w", err)
 	}
 
-	// Update product stock
-	if err := s.updateProductStock(ctx, order.Items, -1); err != nil {
+	// Take the product stock held for the order
+	if err := s.takeStock(ctx, order); err != nil {
 		// Log error but don't fail the payment
 		fmt.Printf("failed to update product stock: %v\n", err)
 	}
 
+	s.printOrder(ctx, order)
+
+	return order, nil
+}
+
+// MarkReady marks a paid order as ready for pickup. The time between creation and
+// readiness feeds the stand wait-time estimates.
+func (s *Service) MarkReady(ctx context.Context, orderID uuid.UUID, staffID *uuid.UUID) (*Order, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if order.Status != OrderStatusPaid {
+		return nil, fmt.Errorf("only paid orders can be marked ready")
+	}
+	if order.ReadyAt != nil {
+		return order, nil
+	}
+
+	now := time.Now()
+	order.ReadyAt = &now
+	if staffID != nil {
+		order.StaffID = staffID
+	}
+	order.UpdatedAt = now
+
+	if err := s.repo.UpdateOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+
 	return order, nil
 }
 
@@ -176,6 +245,9 @@ func (s *Service) CancelOrder(ctx context.Context, orderID uuid.UUID, reason str
 	if order.Status != OrderStatusPending {
 		return nil, fmt.Errorf("only pending orders can be cancelled")
 	}
+	if err := s.checkDayOpen(ctx, order.FestivalID, order.StandID, order.CreatedAt); err != nil {
+		return nil, err
+	}
 
 	order.Status = OrderStatusCancelled
 	order.Notes = reason
@@ -186,9 +258,132 @@ func (s *Service) CancelOrder(ctx context.Context, orderID uuid.UUID, reason str
 		return nil, fmt.Errorf("failed to cancel order: %w", err)
 	}
 
+	s.releaseStock(ctx, order)
+
 	return order, nil
 }
 
+// VoidOrder voids a pending order that was entered by mistake. Unlike a cancellation
+// the order is recorded as a staff error with a reason code.
+func (s *Service) VoidOrder(ctx context.Context, orderID uuid.UUID, req VoidOrderRequest, staffID *uuid.UUID) (*Order, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if order.Status != OrderStatusPending {
+		return nil, fmt.Errorf("only pending orders can be voided, use a correction for paid orders")
+	}
+	if err := s.checkDayOpen(ctx, order.FestivalID, order.StandID, order.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reason := req.Reason
+	order.Status = OrderStatusVoided
+	order.VoidReason = &reason
+	order.VoidedAt = &now
+	if req.Note != "" {
+		order.Notes = req.Note
+	}
+	order.StaffID = staffID
+	order.UpdatedAt = now
+
+	if err := s.repo.UpdateOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to void order: %w", err)
+	}
+
+	s.releaseStock(ctx, order)
+
+	return order, nil
+}
+
+// CorrectOrder voids a paid order that was rung up with the wrong items and creates a
+// linked replacement order with the right ones. Wallet payments are refunded and
+// charged again for the corrected total in one atomic wallet operation; cash and card
+// differences are settled at the stand.
+func (s *Service) CorrectOrder(ctx context.Context, orderID uuid.UUID, req CorrectOrderRequest, staffID uuid.UUID) (*Order, error) {
+	original, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if original.Status != OrderStatusPaid {
+		return nil, fmt.Errorf("only paid orders can be corrected")
+	}
+	if err := s.checkDayOpen(ctx, original.FestivalID, original.StandID, original.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	items, totalAmount, priceListID, err := s.buildOrderItems(ctx, original.FestivalID, original.StandID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	replacement := &Order{
+		ID:            uuid.New(),
+		FestivalID:    original.FestivalID,
+		UserID:        original.UserID,
+		WalletID:      original.WalletID,
+		StandID:       original.StandID,
+		Items:         items,
+		TotalAmount:   totalAmount,
+		Status:        OrderStatusPaid,
+		PaymentMethod: original.PaymentMethod,
+		StaffID:       &staffID,
+		PriceListID:   priceListID,
+		Notes:         req.Note,
+		ReplacesID:    &original.ID,
+		CustomFields:  original.CustomFields,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if original.PaymentMethod == PaymentMethodWallet && original.TransactionID != nil {
+		_, tx, err := s.walletService.ReplacePayment(ctx, *original.TransactionID, wallet.PaymentRequest{
+			WalletID:   original.WalletID,
+			Amount:     totalAmount,
+			StandID:    original.StandID,
+			ProductIDs: s.extractProductIDs(items),
+		}, string(req.Reason), staffID)
+		if err != nil {
+			return nil, fmt.Errorf("payment correction failed: %w", err)
+		}
+		replacement.TransactionID = &tx.ID
+	}
+
+	reason := req.Reason
+	original.Status = OrderStatusVoided
+	original.VoidReason = &reason
+	original.VoidedAt = &now
+	original.ReplacedByID = &replacement.ID
+	original.UpdatedAt = now
+
+	if err := s.repo.ReplaceOrder(ctx, original, replacement); err != nil {
+		return nil, fmt.Errorf("failed to correct order: %w", err)
+	}
+
+	// Restore the stock of the voided items and take the corrected ones
+	if err := s.updateProductStock(ctx, original.Items, 1); err != nil {
+		// Log error but don't fail the correction
+		fmt.Printf("failed to restore product stock: %v\n", err)
+	}
+	if err := s.updateProductStock(ctx, items, -1); err != nil {
+		fmt.Printf("failed to update product stock: %v\n", err)
+	}
+
+	s.printOrder(ctx, replacement)
+
+	return replacement, nil
+}
+
 // RefundOrder refunds a paid order
 func (s *Service) RefundOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*Order, error) {
 	order, err := s.repo.GetOrderByID(ctx, orderID)
@@ -202,6 +397,9 @@ func (s *Service) RefundOrder(ctx context.Context, orderID uuid.UUID, reason str
 	if order.Status != OrderStatusPaid {
 		return nil, fmt.Errorf("only paid orders can be refunded")
 	}
+	if err := s.checkDayOpen(ctx, order.FestivalID, order.StandID, order.CreatedAt); err != nil {
+		return nil, err
+	}
 
 	// Process refund based on payment method
 	if order.PaymentMethod == PaymentMethodWallet && order.TransactionID != nil {
@@ -289,6 +487,122 @@ func (s *Service) GetStandStats(ctx context.Context, standID uuid.UUID, startDat
 
 // Helper functions
 
+// buildOrderItems prices the requested items of a stand and checks they can be sold.
+// Items are priced with the stand's active price list, whose ID is returned.
+func (s *Service) buildOrderItems(ctx context.Context, festivalID, standID uuid.UUID, reqItems []OrderItemRequest) ([]OrderItem, int64, *uuid.UUID, error) {
+	// Collect all product IDs for batch fetch (avoids N+1 queries)
+	productIDs := make([]uuid.UUID, len(reqItems))
+	quantityMap := make(map[uuid.UUID]int, len(reqItems))
+	for i, itemReq := range reqItems {
+		productIDs[i] = itemReq.ProductID
+		quantityMap[itemReq.ProductID] = itemReq.Quantity
+	}
+
+	// Fetch all products in a single query
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	var priceList *product.PriceList
+	if s.priceLists != nil {
+		priceList, err = s.priceLists.ActivePriceList(ctx, festivalID, standID)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to get price list: %w", err)
+		}
+	}
+
+	// Create product map for quick lookup
+	productMap := make(map[uuid.UUID]*product.Product, len(products))
+	for i := range products {
+		productMap[products[i].ID] = &products[i]
+	}
+
+	// Validate and build order items
+	items := make([]OrderItem, 0, len(reqItems))
+	var totalAmount int64
+
+	for _, itemReq := range reqItems {
+		prod, exists := productMap[itemReq.ProductID]
+		if !exists {
+			return nil, 0, nil, fmt.Errorf("product %s not found", itemReq.ProductID)
+		}
+
+		// Verify product belongs to the stand
+		if prod.StandID != standID {
+			return nil, 0, nil, fmt.Errorf("product %s does not belong to stand %s", itemReq.ProductID, standID)
+		}
+
+		// Check product availability
+		if prod.Status != product.ProductStatusActive {
+			return nil, 0, nil, fmt.Errorf("product %s is not available", prod.Name)
+		}
+
+		// Check stock if applicable
+		if prod.Stock != nil && *prod.Stock < itemReq.Quantity {
+			return nil, 0, nil, fmt.Errorf("insufficient stock for product %s", prod.Name)
+		}
+
+		unitPrice := prod.Price
+		if priceList != nil {
+			unitPrice = priceList.PriceFor(prod)
+		}
+
+		var allergens []string
+		for _, a := range prod.Allergens {
+			allergens = append(allergens, string(a))
+		}
+
+		itemTotal := unitPrice * int64(itemReq.Quantity)
+		items = append(items, OrderItem{
+			ProductID:   prod.ID,
+			ProductName: prod.Name,
+			Quantity:    itemReq.Quantity,
+			UnitPrice:   unitPrice,
+			TotalPrice:  itemTotal,
+			UnitCost:    prod.CostPrice,
+			Allergens:   allergens,
+		})
+		totalAmount += itemTotal
+	}
+
+	if priceList == nil {
+		return items, totalAmount, nil, nil
+	}
+	return items, totalAmount, &priceList.ID, nil
+}
+
+// checkDayOpen rejects changes to the orders of a closed business day, unless made by
+// a day close adjustment
+func (s *Service) checkDayOpen(ctx context.Context, festivalID, standID uuid.UUID, at time.Time) error {
+	if s.closedDays == nil {
+		return nil
+	}
+	if adjustment, _ := ctx.Value(closedDayAdjustmentKey{}).(bool); adjustment {
+		return nil
+	}
+
+	closed, err := s.closedDays.IsDayClosed(ctx, festivalID, standID, at)
+	if err != nil {
+		return fmt.Errorf("failed to check day close: %w", err)
+	}
+	if closed {
+		return errors.ErrDayClosed
+	}
+	return nil
+}
+
+// printOrder queues the ticket of a paid order, without failing the payment when the
+// print jobs cannot be created
+func (s *Service) printOrder(ctx context.Context, order *Order) {
+	if s.printer == nil {
+		return
+	}
+	if err := s.printer.PrintOrder(ctx, order); err != nil {
+		fmt.Printf("failed to queue order ticket: %v\n", err)
+	}
+}
+
 func (s *Service) extractProductIDs(items []OrderItem) []string {
 	ids := make([]string, len(items))
 	for i, item := range items {
@@ -302,7 +616,12 @@ func (s *Service) updateProductStock(ctx context.Context, items []OrderItem, mul
 		return nil
 	}
 
-	// Build bulk stock updates to avoid N+1 queries
+	// Single transaction for all stock updates
+	return s.productRepo.UpdateStockBulk(ctx, stockUpdates(items, multiplier))
+}
+
+// stockUpdates builds bulk stock updates to avoid N+1 queries
+func stockUpdates(items []OrderItem, multiplier int) []product.StockUpdate {
 	updates := make([]product.StockUpdate, len(items))
 	for i, item := range items {
 		updates[i] = product.StockUpdate{
@@ -310,7 +629,5 @@ func (s *Service) updateProductStock(ctx context.Context, items []OrderItem, mul
 			Delta:     item.Quantity * multiplier,
 		}
 	}
-
-	// Single transaction for all stock updates
-	return s.productRepo.UpdateStockBulk(ctx, updates)
+	return updates
 }
@@ -0,0 +1,67 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+)
+
+// DefaultStockHoldTTL is how long the stock of a pending order stays held
+const DefaultStockHoldTTL = 15 * time.Minute
+
+// StockReserver holds the stock of pending orders until they are paid or cancelled;
+// satisfied by product.StockReservationRepository
+type StockReserver interface {
+	Reserve(ctx context.Context, orderID uuid.UUID, holds []product.StockHold, now, expiresAt time.Time) error
+	Convert(ctx context.Context, orderID uuid.UUID, updates []product.StockUpdate) error
+	Release(ctx context.Context, orderID uuid.UUID) error
+}
+
+// SetStockReserver holds the stock of orders from their creation for ttl, instead of
+// only taking it at payment
+func (s *Service) SetStockReserver(reserver StockReserver, ttl time.Duration) {
+	s.reserver = reserver
+	s.stockHoldTTL = ttl
+}
+
+// reserveStock holds the stock of a new order, failing with product.ErrInsufficientStock
+// when other orders already hold it
+func (s *Service) reserveStock(ctx context.Context, order *Order) error {
+	if s.reserver == nil {
+		return nil
+	}
+
+	holds := make([]product.StockHold, len(order.Items))
+	for i, item := range order.Items {
+		holds[i] = product.StockHold{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	return s.reserver.Reserve(ctx, order.ID, holds, order.CreatedAt, order.CreatedAt.Add(s.stockHoldTTL))
+}
+
+// takeStock takes the stock of a paid order, converting its holds
+func (s *Service) takeStock(ctx context.Context, order *Order) error {
+	if s.reserver == nil {
+		return s.updateProductStock(ctx, order.Items, -1)
+	}
+	return s.reserver.Convert(ctx, order.ID, stockUpdates(order.Items, -1))
+}
+
+// releaseStock gives back the stock held for an order, without failing the order
+// update when the holds cannot be released; they still expire
+func (s *Service) releaseStock(ctx context.Context, order *Order) {
+	if s.reserver == nil {
+		return
+	}
+	if err := s.reserver.Release(ctx, order.ID); err != nil {
+		fmt.Printf("failed to release stock reservations: %v\n", err)
+	}
+}
+
+// isInsufficientStock reports whether err is a failure to hold the stock of an order
+func isInsufficientStock(err error) bool {
+	return errors.Is(err, product.ErrInsufficientStock)
+}
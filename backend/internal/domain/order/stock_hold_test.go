@@ -0,0 +1,122 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStockReserver struct {
+	holds     []product.StockHold
+	expiresAt time.Time
+	converted []product.StockUpdate
+	released  []uuid.UUID
+	err       error
+}
+
+func (r *fakeStockReserver) Reserve(ctx context.Context, orderID uuid.UUID, holds []product.StockHold, now, expiresAt time.Time) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.holds = holds
+	r.expiresAt = expiresAt
+	return nil
+}
+
+func (r *fakeStockReserver) Convert(ctx context.Context, orderID uuid.UUID, updates []product.StockUpdate) error {
+	r.converted = updates
+	return nil
+}
+
+func (r *fakeStockReserver) Release(ctx context.Context, orderID uuid.UUID) error {
+	r.released = append(r.released, orderID)
+	return nil
+}
+
+type fakeOrderRepository struct {
+	Repository
+	orders map[uuid.UUID]*Order
+	err    error
+}
+
+func (r *fakeOrderRepository) CreateOrder(ctx context.Context, order *Order) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *fakeOrderRepository) GetOrderByID(ctx context.Context, id uuid.UUID) (*Order, error) {
+	return r.orders[id], nil
+}
+
+func (r *fakeOrderRepository) UpdateOrder(ctx context.Context, order *Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+func newStockHoldService(repo *fakeOrderRepository, reserver *fakeStockReserver, products ...product.Product) *Service {
+	productRepo := product.NewMockRepository()
+	productRepo.On("GetByIDs", mock.Anything, mock.Anything).Return(products, nil)
+	service := NewService(repo, productRepo, nil)
+	service.SetStockReserver(reserver, DefaultStockHoldTTL)
+	return service
+}
+
+func TestCreateOrder_HoldsStock(t *testing.T) {
+	standID := uuid.New()
+	stock := 5
+	beer := product.Product{ID: uuid.New(), StandID: standID, Name: "Beer", Price: 500, Stock: &stock, Status: product.ProductStatusActive}
+	repo := &fakeOrderRepository{orders: map[uuid.UUID]*Order{}}
+	reserver := &fakeStockReserver{}
+	service := newStockHoldService(repo, reserver, beer)
+	req := CreateOrderRequest{StandID: standID, PaymentMethod: PaymentMethodCash, Items: []OrderItemRequest{{ProductID: beer.ID, Quantity: 2}}}
+
+	order, err := service.CreateOrder(context.Background(), uuid.New(), uuid.New(), uuid.New(), req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []product.StockHold{{ProductID: beer.ID, Quantity: 2}}, reserver.holds)
+	assert.Equal(t, order.CreatedAt.Add(DefaultStockHoldTTL), reserver.expiresAt)
+
+	// Paying converts the holds into a stock decrement
+	_, err = service.ProcessPayment(context.Background(), order.ID, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, []product.StockUpdate{{ProductID: beer.ID, Delta: -2}}, reserver.converted)
+	assert.Empty(t, reserver.released)
+
+	// Stock held by other orders rejects the order before it is created
+	reserver.err = fmt.Errorf("%w for product Beer", product.ErrInsufficientStock)
+	_, err = service.CreateOrder(context.Background(), uuid.New(), uuid.New(), uuid.New(), req, nil)
+	assert.True(t, isInsufficientStock(err))
+	assert.Len(t, repo.orders, 1)
+}
+
+func TestCancelOrder_ReleasesStock(t *testing.T) {
+	order := &Order{ID: uuid.New(), Status: OrderStatusPending}
+	repo := &fakeOrderRepository{orders: map[uuid.UUID]*Order{order.ID: order}}
+	reserver := &fakeStockReserver{}
+	service := newStockHoldService(repo, reserver)
+
+	_, err := service.CancelOrder(context.Background(), order.ID, "changed mind", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{order.ID}, reserver.released)
+
+	// A failed creation gives back the stock it held
+	standID := uuid.New()
+	stock := 5
+	beer := product.Product{ID: uuid.New(), StandID: standID, Name: "Beer", Price: 500, Stock: &stock, Status: product.ProductStatusActive}
+	repo.err = fmt.Errorf("connection reset")
+	service = newStockHoldService(repo, reserver, beer)
+	req := CreateOrderRequest{StandID: standID, PaymentMethod: PaymentMethodCash, Items: []OrderItemRequest{{ProductID: beer.ID, Quantity: 1}}}
+
+	_, err = service.CreateOrder(context.Background(), uuid.New(), uuid.New(), uuid.New(), req, nil)
+	require.Error(t, err)
+	assert.Len(t, reserver.released, 2)
+}
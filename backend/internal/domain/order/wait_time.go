@@ -0,0 +1,306 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// openOrderMaxAge ignores paid orders that were never marked ready after this long
+	openOrderMaxAge = 2 * time.Hour
+	// fallbackServiceTime is used per open order when a stand has no recent completed orders
+	fallbackServiceTime = 2 * time.Minute
+)
+
+// Wait-time alert levels
+const (
+	WaitLevelNormal   = "NORMAL"
+	WaitLevelWarning  = "WARNING"
+	WaitLevelCritical = "CRITICAL"
+)
+
+// WaitTimeConfig configures the wait-time estimation
+type WaitTimeConfig struct {
+	Window          time.Duration // Rolling window of completed orders used for the estimate
+	RefreshInterval time.Duration // How often estimates are recomputed
+	WarningMinutes  int           // Organizers get a warning above this wait
+	CriticalMinutes int           // Organizers get an error alert above this wait
+	AlertCooldown   time.Duration // Minimum delay between two alerts of the same level for a stand
+}
+
+// DefaultWaitTimeConfig returns the default wait-time configuration
+func DefaultWaitTimeConfig() WaitTimeConfig {
+	return WaitTimeConfig{
+		Window:          30 * time.Minute,
+		RefreshInterval: time.Minute,
+		WarningMinutes:  15,
+		CriticalMinutes: 30,
+		AlertCooldown:   15 * time.Minute,
+	}
+}
+
+// StandThroughput is the recent order activity of a stand
+type StandThroughput struct {
+	StandID         uuid.UUID `gorm:"column:stand_id"`
+	StandName       string    `gorm:"column:stand_name"`
+	CompletedOrders int64     `gorm:"column:completed_orders"`
+	AvgPrepSeconds  float64   `gorm:"column:avg_prep_seconds"`
+	OpenOrders      int64     `gorm:"column:open_orders"`
+}
+
+// WaitTimeEstimate is the estimated wait at a stand
+type WaitTimeEstimate struct {
+	StandID          uuid.UUID `json:"standId"`
+	StandName        string    `json:"standName"`
+	EstimatedMinutes int       `json:"estimatedMinutes"`
+	AvgPrepSeconds   int       `json:"avgPrepSeconds"`
+	OpenOrders       int64     `json:"openOrders"`
+	OrdersPerMinute  float64   `json:"ordersPerMinute"`
+	SampleSize       int64     `json:"sampleSize"`
+	Level            string    `json:"level"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// EstimateWaitTime estimates how long a new order would wait at a stand. It takes the
+// longer of the average creation-to-ready time and the time needed to clear the open
+// orders at the recent throughput.
+func EstimateWaitTime(t StandThroughput, window time.Duration) time.Duration {
+	if t.CompletedOrders == 0 {
+		return time.Duration(t.OpenOrders) * fallbackServiceTime
+	}
+
+	avgPrep := time.Duration(t.AvgPrepSeconds * float64(time.Second))
+	perOrder := window / time.Duration(t.CompletedOrders)
+	queue := time.Duration(t.OpenOrders) * perOrder
+
+	if queue > avgPrep {
+		return queue
+	}
+	return avgPrep
+}
+
+// WaitTimeAlerter broadcasts organizer alerts; satisfied by realtime.Service
+type WaitTimeAlerter interface {
+	BroadcastAlert(festivalID string, alert *realtime.Alert)
+}
+
+// WaitTimeService keeps rolling per-stand wait-time estimates in Redis
+type WaitTimeService struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	alerter     WaitTimeAlerter
+	config      WaitTimeConfig
+	stop        chan struct{}
+}
+
+// NewWaitTimeService creates a new wait-time service
+func NewWaitTimeService(repo Repository, redisClient *redis.Client, config WaitTimeConfig) *WaitTimeService {
+	return &WaitTimeService{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+		config:      config,
+		stop:        make(chan struct{}),
+	}
+}
+
+// SetAlerter sets the service used to alert organizers about long waits
+func (s *WaitTimeService) SetAlerter(alerter WaitTimeAlerter) {
+	s.alerter = alerter
+}
+
+// Start recomputes the estimates of all active festivals periodically until Stop is called
+func (s *WaitTimeService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), s.config.RefreshInterval)
+				if err := s.RefreshAll(ctx); err != nil {
+					log.Error().Err(err).Msg("Failed to refresh stand wait times")
+				}
+				cancel()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic refresh
+func (s *WaitTimeService) Stop() {
+	close(s.stop)
+}
+
+// RefreshAll recomputes the estimates of every festival with recent order activity
+func (s *WaitTimeService) RefreshAll(ctx context.Context) error {
+	festivalIDs, err := s.repo.GetFestivalsWithOpenOrders(ctx, time.Now().Add(-s.config.Window))
+	if err != nil {
+		return err
+	}
+
+	for _, festivalID := range festivalIDs {
+		if _, err := s.Refresh(ctx, festivalID); err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to refresh festival wait times")
+		}
+	}
+	return nil
+}
+
+// Refresh recomputes and stores the estimates of a festival's stands
+func (s *WaitTimeService) Refresh(ctx context.Context, festivalID uuid.UUID) ([]WaitTimeEstimate, error) {
+	now := time.Now()
+	throughput, err := s.repo.GetStandThroughput(ctx, festivalID, now.Add(-s.config.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make([]WaitTimeEstimate, len(throughput))
+	for i, t := range throughput {
+		wait := EstimateWaitTime(t, s.config.Window)
+		estimates[i] = WaitTimeEstimate{
+			StandID:          t.StandID,
+			StandName:        t.StandName,
+			EstimatedMinutes: int(math.Ceil(wait.Minutes())),
+			AvgPrepSeconds:   int(t.AvgPrepSeconds),
+			OpenOrders:       t.OpenOrders,
+			OrdersPerMinute:  float64(t.CompletedOrders) / s.config.Window.Minutes(),
+			SampleSize:       t.CompletedOrders,
+			UpdatedAt:        now,
+		}
+		estimates[i].Level = s.level(estimates[i].EstimatedMinutes)
+	}
+
+	if err := s.store(ctx, festivalID, estimates); err != nil {
+		return nil, err
+	}
+
+	for _, estimate := range estimates {
+		if estimate.Level != WaitLevelNormal {
+			s.alert(ctx, festivalID, estimate)
+		}
+	}
+
+	return estimates, nil
+}
+
+// GetFestivalWaitTimes returns the stored estimates of a festival's stands
+func (s *WaitTimeService) GetFestivalWaitTimes(ctx context.Context, festivalID uuid.UUID) ([]WaitTimeEstimate, error) {
+	values, err := s.redisClient.HGetAll(ctx, s.keyBuilder.StandWaitTimesKey(festivalID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wait times: %w", err)
+	}
+
+	estimates := make([]WaitTimeEstimate, 0, len(values))
+	for _, value := range values {
+		var estimate WaitTimeEstimate
+		if err := json.Unmarshal([]byte(value), &estimate); err != nil {
+			continue
+		}
+		estimates = append(estimates, estimate)
+	}
+	return estimates, nil
+}
+
+// GetStandWaitTime returns the stored estimate of a stand, or nil when there is none
+func (s *WaitTimeService) GetStandWaitTime(ctx context.Context, festivalID, standID uuid.UUID) (*WaitTimeEstimate, error) {
+	value, err := s.redisClient.HGet(ctx, s.keyBuilder.StandWaitTimesKey(festivalID), standID.String()).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand wait time: %w", err)
+	}
+
+	var estimate WaitTimeEstimate
+	if err := json.Unmarshal([]byte(value), &estimate); err != nil {
+		return nil, fmt.Errorf("failed to decode stand wait time: %w", err)
+	}
+	return &estimate, nil
+}
+
+// GetWaitMinutes returns the estimated wait in minutes per stand of a festival;
+// stands without recent activity are omitted
+func (s *WaitTimeService) GetWaitMinutes(ctx context.Context, festivalID uuid.UUID) (map[uuid.UUID]int, error) {
+	estimates, err := s.GetFestivalWaitTimes(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := make(map[uuid.UUID]int, len(estimates))
+	for _, estimate := range estimates {
+		minutes[estimate.StandID] = estimate.EstimatedMinutes
+	}
+	return minutes, nil
+}
+
+func (s *WaitTimeService) level(minutes int) string {
+	switch {
+	case s.config.CriticalMinutes > 0 && minutes >= s.config.CriticalMinutes:
+		return WaitLevelCritical
+	case s.config.WarningMinutes > 0 && minutes >= s.config.WarningMinutes:
+		return WaitLevelWarning
+	default:
+		return WaitLevelNormal
+	}
+}
+
+// store replaces the festival estimates so stands without recent activity drop out
+func (s *WaitTimeService) store(ctx context.Context, festivalID uuid.UUID, estimates []WaitTimeEstimate) error {
+	key := s.keyBuilder.StandWaitTimesKey(festivalID)
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	for _, estimate := range estimates {
+		data, err := json.Marshal(estimate)
+		if err != nil {
+			return fmt.Errorf("failed to encode wait time: %w", err)
+		}
+		pipe.HSet(ctx, key, estimate.StandID.String(), data)
+	}
+	// Keep estimates a few refreshes so a stalled refresher does not serve stale data forever
+	pipe.Expire(ctx, key, 5*s.config.RefreshInterval)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store wait times: %w", err)
+	}
+	return nil
+}
+
+// alert notifies organizers once per cooldown period and level
+func (s *WaitTimeService) alert(ctx context.Context, festivalID uuid.UUID, estimate WaitTimeEstimate) {
+	if s.alerter == nil {
+		return
+	}
+
+	acquired, err := s.redisClient.SetNX(ctx, s.keyBuilder.StandWaitAlertKey(estimate.StandID, estimate.Level), 1, s.config.AlertCooldown).Result()
+	if err != nil || !acquired {
+		return
+	}
+
+	alertType := "warning"
+	if estimate.Level == WaitLevelCritical {
+		alertType = "error"
+	}
+
+	s.alerter.BroadcastAlert(festivalID.String(), &realtime.Alert{
+		ID:        uuid.New().String(),
+		Type:      alertType,
+		Title:     "Long wait at " + estimate.StandName,
+		Message:   fmt.Sprintf("Estimated wait is %d minutes with %d open orders", estimate.EstimatedMinutes, estimate.OpenOrders),
+		ActionURL: fmt.Sprintf("/festivals/%s/stands/%s", festivalID, estimate.StandID),
+	})
+}
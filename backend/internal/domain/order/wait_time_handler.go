@@ -0,0 +1,122 @@
+package order
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// WaitTimeHandler exposes the stand wait-time estimates
+type WaitTimeHandler struct {
+	service *WaitTimeService
+}
+
+func NewWaitTimeHandler(service *WaitTimeService) *WaitTimeHandler {
+	return &WaitTimeHandler{service: service}
+}
+
+// RegisterRoutes registers festival-scoped wait-time routes
+func (h *WaitTimeHandler) RegisterRoutes(r *gin.RouterGroup) {
+	waitTimes := r.Group("/wait-times")
+	{
+		waitTimes.GET("", h.List)
+		waitTimes.POST("/refresh", h.Refresh)
+		waitTimes.GET("/:standId", h.GetByStand)
+	}
+}
+
+// List returns the wait-time estimates of all stands with recent orders
+// @Summary List stand wait times
+// @Description Get the estimated wait per stand, computed from recent order throughput
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]WaitTimeEstimate} "Wait times"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wait-times [get]
+func (h *WaitTimeHandler) List(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	estimates, err := h.service.GetFestivalWaitTimes(c.Request.Context(), festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, estimates)
+}
+
+// Refresh recomputes the wait-time estimates of the festival
+// @Summary Refresh stand wait times
+// @Description Recompute the wait-time estimates now instead of waiting for the next periodic refresh
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]WaitTimeEstimate} "Wait times"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wait-times/refresh [post]
+func (h *WaitTimeHandler) Refresh(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	estimates, err := h.service.Refresh(c.Request.Context(), festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, estimates)
+}
+
+// GetByStand returns the wait-time estimate of a stand
+// @Summary Get stand wait time
+// @Description Get the estimated wait at a stand
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId path string true "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=WaitTimeEstimate} "Wait time"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No recent orders at this stand"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wait-times/{standId} [get]
+func (h *WaitTimeHandler) GetByStand(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	standID, err := uuid.Parse(c.Param("standId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+		return
+	}
+
+	estimate, err := h.service.GetStandWaitTime(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+	if estimate == nil {
+		response.NotFound(c, "No wait-time estimate for this stand")
+		return
+	}
+
+	response.OK(c, estimate)
+}
@@ -0,0 +1,118 @@
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateWaitTime(t *testing.T) {
+	window := 30 * time.Minute
+
+	tests := []struct {
+		name       string
+		throughput StandThroughput
+		window     time.Duration
+		want       time.Duration
+	}{
+		{
+			name:       "no completed orders falls back per open order",
+			throughput: StandThroughput{OpenOrders: 4},
+			window:     window,
+			want:       4 * fallbackServiceTime,
+		},
+		{
+			name:       "no completed nor open orders",
+			throughput: StandThroughput{},
+			window:     window,
+			want:       0,
+		},
+		{
+			name:       "queue at the recent throughput",
+			throughput: StandThroughput{CompletedOrders: 10, AvgPrepSeconds: 120, OpenOrders: 8},
+			window:     window,
+			want:       24 * time.Minute, // 3 minutes per order
+		},
+		{
+			name:       "prep time floors a short queue",
+			throughput: StandThroughput{CompletedOrders: 60, AvgPrepSeconds: 420, OpenOrders: 2},
+			window:     window,
+			want:       7 * time.Minute, // Queue clears in 1 minute
+		},
+		{
+			name:       "prep time without open orders",
+			throughput: StandThroughput{CompletedOrders: 5, AvgPrepSeconds: 90.5},
+			window:     window,
+			want:       90500 * time.Millisecond,
+		},
+		{
+			name:       "empty window leaves the prep time",
+			throughput: StandThroughput{CompletedOrders: 10, AvgPrepSeconds: 150, OpenOrders: 8},
+			window:     0,
+			want:       150 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EstimateWaitTime(tt.throughput, tt.window))
+		})
+	}
+}
+
+type fakeWaitTimeRepository struct {
+	Repository
+	throughput []StandThroughput
+}
+
+func (r *fakeWaitTimeRepository) GetStandThroughput(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]StandThroughput, error) {
+	return r.throughput, nil
+}
+
+type fakeWaitTimeAlerter struct {
+	alerts []*realtime.Alert
+}
+
+func (a *fakeWaitTimeAlerter) BroadcastAlert(festivalID string, alert *realtime.Alert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+func TestWaitTimeService_Refresh(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	festivalID := uuid.New()
+	bar := StandThroughput{StandID: uuid.New(), StandName: "Main Bar", CompletedOrders: 10, AvgPrepSeconds: 120, OpenOrders: 8}
+	food := StandThroughput{StandID: uuid.New(), StandName: "Food Court", CompletedOrders: 60, AvgPrepSeconds: 300, OpenOrders: 2}
+
+	alerter := &fakeWaitTimeAlerter{}
+	service := NewWaitTimeService(&fakeWaitTimeRepository{throughput: []StandThroughput{bar, food}},
+		redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), DefaultWaitTimeConfig())
+	service.SetAlerter(alerter)
+	ctx := context.Background()
+
+	estimates, err := service.Refresh(ctx, festivalID)
+	require.NoError(t, err)
+	require.Len(t, estimates, 2)
+	assert.Equal(t, 24, estimates[0].EstimatedMinutes)
+	assert.Equal(t, WaitLevelWarning, estimates[0].Level)
+	assert.InDelta(t, 10.0/30, estimates[0].OrdersPerMinute, 0.001)
+	assert.Equal(t, 5, estimates[1].EstimatedMinutes)
+	assert.Equal(t, WaitLevelNormal, estimates[1].Level)
+
+	stored, err := service.GetStandWaitTime(ctx, festivalID, bar.StandID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 24, stored.EstimatedMinutes)
+
+	// Alerted once per cooldown, however often it is refreshed
+	_, err = service.Refresh(ctx, festivalID)
+	require.NoError(t, err)
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "warning", alerter.alerts[0].Type)
+}
@@ -0,0 +1,203 @@
+package orderfield
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the management of the custom order fields, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	fields := r.Group("/order-fields")
+	{
+		fields.POST("", h.CreateField)
+		fields.PATCH("/:fieldId", h.UpdateField)
+	}
+}
+
+// RegisterAttendeeRoutes registers the lookup of the fields to fill in when ordering,
+// open to every authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	fields := r.Group("/order-fields")
+	{
+		fields.GET("", h.ListFields)
+		fields.GET("/:fieldId", h.GetField)
+	}
+}
+
+// CreateField adds a custom field to the orders of the festival
+// @Summary Create order field
+// @Description Add a typed custom field to the orders of the festival, e.g. a table number or an allergy note. The key is used in orders and in the field[key] filter of order lists, and cannot change.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateFieldRequest true "Order field"
+// @Success 201 {object} response.Response{data=Field} "Created field"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Key already used"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-fields [post]
+func (h *Handler) CreateField(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	field, err := h.service.CreateField(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, field)
+}
+
+// UpdateField changes a custom order field
+// @Summary Update order field
+// @Description Change a custom order field, or stop asking for it with active=false. The values already entered on orders are kept.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param fieldId path string true "Field ID" format(uuid)
+// @Param request body UpdateFieldRequest true "Changes"
+// @Success 200 {object} response.Response{data=Field} "Updated field"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Field not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-fields/{fieldId} [patch]
+func (h *Handler) UpdateField(c *gin.Context) {
+	festivalID, fieldID, ok := fieldParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	field, err := h.service.UpdateField(c.Request.Context(), festivalID, fieldID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, field)
+}
+
+// ListFields lists the custom order fields of the festival
+// @Summary List order fields
+// @Description List the custom fields to fill in when ordering, in the order to ask for them. all=true also lists the inactive fields.
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param all query bool false "Include the inactive fields"
+// @Success 200 {object} response.Response{data=[]Field} "Order fields"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-fields [get]
+func (h *Handler) ListFields(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	fields, err := h.service.ListFields(c.Request.Context(), festivalID, c.Query("all") != "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, fields)
+}
+
+// GetField returns a custom order field
+// @Summary Get order field
+// @Tags orders
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param fieldId path string true "Field ID" format(uuid)
+// @Success 200 {object} response.Response{data=Field} "Order field"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Field not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/order-fields/{fieldId} [get]
+func (h *Handler) GetField(c *gin.Context) {
+	festivalID, fieldID, ok := fieldParams(c)
+	if !ok {
+		return
+	}
+
+	field, err := h.service.GetField(c.Request.Context(), festivalID, fieldID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, field)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func fieldParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	fieldID, err := uuid.Parse(c.Param("fieldId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid field ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, fieldID, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFieldNotFound):
+		response.NotFound(c, "Order field not found")
+	case errors.Is(err, ErrInvalidKey):
+		response.BadRequest(c, "INVALID_KEY", err.Error(), nil)
+	case errors.Is(err, ErrInvalidOptions):
+		response.BadRequest(c, "INVALID_OPTIONS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidBounds):
+		response.BadRequest(c, "INVALID_BOUNDS", err.Error(), nil)
+	case errors.Is(err, ErrTooManyFields):
+		response.BadRequest(c, "TOO_MANY_FIELDS", err.Error(), nil)
+	case errors.Is(err, ErrKeyTaken):
+		response.Conflict(c, "KEY_TAKEN", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,91 @@
+package orderfield
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Order field errors
+var (
+	ErrFieldNotFound  = errors.New("order field not found")
+	ErrKeyTaken       = errors.New("another order field of the festival has this key")
+	ErrInvalidKey     = errors.New("key must be lowercase letters, digits and underscores, starting with a letter")
+	ErrInvalidOptions = errors.New("select fields need between 1 and 50 distinct options")
+	ErrInvalidBounds  = errors.New("min cannot be greater than max")
+	ErrTooManyFields  = errors.New("a festival can have at most 20 active order fields")
+)
+
+// MaxActiveFields is the number of active fields a festival can ask for on orders
+const MaxActiveFields = 20
+
+// DefaultMaxLength is the length of text values when a field sets none
+const DefaultMaxLength = 255
+
+// FieldType is the type of the values of a field
+type FieldType string
+
+const (
+	FieldTypeText    FieldType = "TEXT"    // Free text, e.g. an allergy note
+	FieldTypeNumber  FieldType = "NUMBER"  // e.g. a token count, within min and max
+	FieldTypeBoolean FieldType = "BOOLEAN" // Yes or no
+	FieldTypeSelect  FieldType = "SELECT"  // One of the options
+)
+
+// Field is a custom field organizers ask for on the orders of their festival, e.g. a
+// table number. Its key and type cannot change once orders may carry its values.
+type Field struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID    uuid.UUID      `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Key           string         `json:"key" gorm:"not null"` // e.g. "table", used in order lists filters
+	Label         string         `json:"label" gorm:"not null"`
+	Type          FieldType      `json:"type" gorm:"not null"`
+	Required      bool           `json:"required" gorm:"not null"`
+	Options       pq.StringArray `json:"options,omitempty" gorm:"type:text[]"` // Select fields only
+	MaxLength     int            `json:"maxLength,omitempty"`                  // Text fields only; 0 for DefaultMaxLength
+	Min           *float64       `json:"min,omitempty"`                        // Number fields only
+	Max           *float64       `json:"max,omitempty"`
+	Integer       bool           `json:"integer,omitempty" gorm:"column:integer_only"` // Number fields only accept whole numbers
+	SortOrder     int            `json:"sortOrder" gorm:"not null"`
+	PrintOnTicket bool           `json:"printOnTicket" gorm:"not null"` // Printed on the stand ticket of the order
+	Active        bool           `json:"active" gorm:"not null"`        // Asked for on new orders
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+func (Field) TableName() string {
+	return "order_custom_fields"
+}
+
+// CreateFieldRequest adds a custom field to the orders of the festival
+type CreateFieldRequest struct {
+	Key           string    `json:"key" binding:"required,max=40"`
+	Label         string    `json:"label" binding:"required,max=100"`
+	Type          FieldType `json:"type" binding:"required,oneof=TEXT NUMBER BOOLEAN SELECT"`
+	Required      bool      `json:"required"`
+	Options       []string  `json:"options,omitempty" binding:"omitempty,max=50,dive,max=100"`
+	MaxLength     int       `json:"maxLength,omitempty" binding:"min=0,max=1000"`
+	Min           *float64  `json:"min,omitempty"`
+	Max           *float64  `json:"max,omitempty"`
+	Integer       bool      `json:"integer,omitempty"`
+	SortOrder     int       `json:"sortOrder"`
+	PrintOnTicket bool      `json:"printOnTicket"`
+}
+
+// UpdateFieldRequest changes a custom field; the values already entered on orders are
+// kept as they are
+type UpdateFieldRequest struct {
+	Label         *string   `json:"label,omitempty" binding:"omitempty,max=100"`
+	Required      *bool     `json:"required,omitempty"`
+	Options       *[]string `json:"options,omitempty" binding:"omitempty,max=50,dive,max=100"`
+	MaxLength     *int      `json:"maxLength,omitempty" binding:"omitempty,min=0,max=1000"`
+	Min           *float64  `json:"min,omitempty"`
+	Max           *float64  `json:"max,omitempty"`
+	ClearBounds   bool      `json:"clearBounds,omitempty"` // Removes min and max
+	Integer       *bool     `json:"integer,omitempty"`
+	SortOrder     *int      `json:"sortOrder,omitempty"`
+	PrintOnTicket *bool     `json:"printOnTicket,omitempty"`
+	Active        *bool     `json:"active,omitempty"`
+}
@@ -0,0 +1,82 @@
+package orderfield
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateField(ctx context.Context, field *Field) error
+	UpdateField(ctx context.Context, field *Field) error
+	GetField(ctx context.Context, festivalID, id uuid.UUID) (*Field, error)
+	ListFields(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Field, error)
+	CountActiveFields(ctx context.Context, festivalID uuid.UUID) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateField(ctx context.Context, field *Field) error {
+	if err := r.db.WithContext(ctx).Create(field).Error; err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrKeyTaken
+		}
+		return fmt.Errorf("failed to create order field: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateField(ctx context.Context, field *Field) error {
+	if err := r.db.WithContext(ctx).Save(field).Error; err != nil {
+		return fmt.Errorf("failed to update order field: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetField(ctx context.Context, festivalID, id uuid.UUID) (*Field, error) {
+	var field Field
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&field).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get order field: %w", err)
+	}
+	return &field, nil
+}
+
+// ListFields lists the fields of a festival in the order they are asked for
+func (r *repository) ListFields(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Field, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	var fields []Field
+	if err := query.Order("sort_order, created_at").Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to list order fields: %w", err)
+	}
+	return fields, nil
+}
+
+func (r *repository) CountActiveFields(ctx context.Context, festivalID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Field{}).
+		Where("festival_id = ? AND active = ?", festivalID, true).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count order fields: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,48 @@
+package orderfield
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateField(ctx context.Context, field *Field) error {
+	args := m.Called(ctx, field)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateField(ctx context.Context, field *Field) error {
+	args := m.Called(ctx, field)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetField(ctx context.Context, festivalID, id uuid.UUID) (*Field, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Field), args.Error(1)
+}
+
+func (m *MockRepository) ListFields(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Field, error) {
+	args := m.Called(ctx, festivalID, activeOnly)
+	return args.Get(0).([]Field), args.Error(1)
+}
+
+func (m *MockRepository) CountActiveFields(ctx context.Context, festivalID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).(int64), args.Error(1)
+}
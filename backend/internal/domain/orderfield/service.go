@@ -0,0 +1,290 @@
+package orderfield
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Service manages the custom fields organizers ask for on orders and checks the values
+// entered on new orders
+type Service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewService creates the order field service
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+// CreateField adds a custom field to the orders of the festival
+func (s *Service) CreateField(ctx context.Context, festivalID uuid.UUID, req CreateFieldRequest) (*Field, error) {
+	key := strings.TrimSpace(req.Key)
+	if !keyPattern.MatchString(key) {
+		return nil, ErrInvalidKey
+	}
+	if err := s.checkActiveLimit(ctx, festivalID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	field := &Field{
+		ID:            uuid.New(),
+		FestivalID:    festivalID,
+		Key:           key,
+		Label:         strings.TrimSpace(req.Label),
+		Type:          req.Type,
+		Required:      req.Required,
+		MaxLength:     req.MaxLength,
+		Min:           req.Min,
+		Max:           req.Max,
+		Integer:       req.Integer,
+		SortOrder:     req.SortOrder,
+		PrintOnTicket: req.PrintOnTicket,
+		Active:        true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if req.Type == FieldTypeSelect {
+		field.Options = req.Options
+	}
+	if err := checkField(field); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateField(ctx, field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// UpdateField changes a custom field. Deactivating it stops asking for it on new
+// orders; the values entered on orders stay.
+func (s *Service) UpdateField(ctx context.Context, festivalID, id uuid.UUID, req UpdateFieldRequest) (*Field, error) {
+	field, err := s.GetField(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		field.Label = strings.TrimSpace(*req.Label)
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+	if req.Options != nil && field.Type == FieldTypeSelect {
+		field.Options = *req.Options
+	}
+	if req.MaxLength != nil {
+		field.MaxLength = *req.MaxLength
+	}
+	if req.ClearBounds {
+		field.Min, field.Max = nil, nil
+	}
+	if req.Min != nil {
+		field.Min = req.Min
+	}
+	if req.Max != nil {
+		field.Max = req.Max
+	}
+	if req.Integer != nil {
+		field.Integer = *req.Integer
+	}
+	if req.SortOrder != nil {
+		field.SortOrder = *req.SortOrder
+	}
+	if req.PrintOnTicket != nil {
+		field.PrintOnTicket = *req.PrintOnTicket
+	}
+	if req.Active != nil && *req.Active != field.Active {
+		if *req.Active {
+			if err := s.checkActiveLimit(ctx, festivalID); err != nil {
+				return nil, err
+			}
+		}
+		field.Active = *req.Active
+	}
+	if err := checkField(field); err != nil {
+		return nil, err
+	}
+	field.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateField(ctx, field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// GetField returns a custom field of the festival
+func (s *Service) GetField(ctx context.Context, festivalID, id uuid.UUID) (*Field, error) {
+	field, err := s.repo.GetField(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if field == nil {
+		return nil, ErrFieldNotFound
+	}
+	return field, nil
+}
+
+// ListFields lists the custom fields of the festival in the order they are asked for,
+// or only the active ones
+func (s *Service) ListFields(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Field, error) {
+	return s.repo.ListFields(ctx, festivalID, activeOnly)
+}
+
+// ValidateOrderFields checks the values entered on a new order against the active
+// fields of the festival, and returns them normalized in the order of the fields
+func (s *Service) ValidateOrderFields(ctx context.Context, festivalID uuid.UUID, values map[string]string) (order.CustomFieldValues, error) {
+	fields, err := s.repo.ListFields(ctx, festivalID, true)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateValues(fields, values)
+}
+
+// ValidateValues checks values by key against fields. Unknown keys and missing
+// required values are refused; blank values of optional fields are left out.
+func ValidateValues(fields []Field, values map[string]string) (order.CustomFieldValues, error) {
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Key] = true
+	}
+	unknown := make([]string, 0)
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: unknown field %s", order.ErrInvalidCustomField, strings.Join(unknown, ", "))
+	}
+
+	result := make(order.CustomFieldValues, 0, len(values))
+	for i := range fields {
+		field := &fields[i]
+		raw := strings.TrimSpace(values[field.Key])
+		if raw == "" {
+			if field.Required {
+				return nil, fmt.Errorf("%w: %s is required", order.ErrInvalidCustomField, field.Label)
+			}
+			continue
+		}
+
+		value, err := NormalizeValue(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, order.CustomFieldValue{
+			Key:           field.Key,
+			Label:         field.Label,
+			Value:         value,
+			PrintOnTicket: field.PrintOnTicket,
+		})
+	}
+	return result, nil
+}
+
+// NormalizeValue checks a value against the type of its field and returns it as stored
+// on orders: numbers without trailing zeros, booleans as "true" or "false", and
+// options as defined
+func NormalizeValue(field *Field, raw string) (string, error) {
+	switch field.Type {
+	case FieldTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return "", fmt.Errorf("%w: %s must be a number", order.ErrInvalidCustomField, field.Label)
+		}
+		if field.Integer && number != math.Trunc(number) {
+			return "", fmt.Errorf("%w: %s must be a whole number", order.ErrInvalidCustomField, field.Label)
+		}
+		if field.Min != nil && number < *field.Min {
+			return "", fmt.Errorf("%w: %s must be at least %s", order.ErrInvalidCustomField, field.Label, formatNumber(*field.Min))
+		}
+		if field.Max != nil && number > *field.Max {
+			return "", fmt.Errorf("%w: %s must be at most %s", order.ErrInvalidCustomField, field.Label, formatNumber(*field.Max))
+		}
+		return formatNumber(number), nil
+
+	case FieldTypeBoolean:
+		switch strings.ToLower(raw) {
+		case "true", "yes", "1":
+			return "true", nil
+		case "false", "no", "0":
+			return "false", nil
+		}
+		return "", fmt.Errorf("%w: %s must be true or false", order.ErrInvalidCustomField, field.Label)
+
+	case FieldTypeSelect:
+		for _, option := range field.Options {
+			if strings.EqualFold(option, raw) {
+				return option, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s must be one of %s", order.ErrInvalidCustomField, field.Label, strings.Join(field.Options, ", "))
+
+	default:
+		maxLength := field.MaxLength
+		if maxLength == 0 {
+			maxLength = DefaultMaxLength
+		}
+		if utf8.RuneCountInString(raw) > maxLength {
+			return "", fmt.Errorf("%w: %s must be at most %d characters", order.ErrInvalidCustomField, field.Label, maxLength)
+		}
+		return raw, nil
+	}
+}
+
+// checkField checks the settings of a field for its type, trimming its options
+func checkField(field *Field) error {
+	if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+		return ErrInvalidBounds
+	}
+	if field.Type != FieldTypeSelect {
+		return nil
+	}
+
+	options := make([]string, 0, len(field.Options))
+	seen := make(map[string]bool, len(field.Options))
+	for _, option := range field.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || seen[strings.ToLower(option)] {
+			return ErrInvalidOptions
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+	if len(options) == 0 || len(options) > 50 {
+		return ErrInvalidOptions
+	}
+	field.Options = options
+	return nil
+}
+
+func (s *Service) checkActiveLimit(ctx context.Context, festivalID uuid.UUID) error {
+	count, err := s.repo.CountActiveFields(ctx, festivalID)
+	if err != nil {
+		return err
+	}
+	if count >= MaxActiveFields {
+		return ErrTooManyFields
+	}
+	return nil
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
@@ -0,0 +1,118 @@
+package orderfield
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(repo Repository) *Service {
+	service := NewService(repo)
+	service.now = func() time.Time { return time.Date(2026, 7, 18, 14, 0, 0, 0, time.UTC) }
+	return service
+}
+
+// expectActiveFields sets the count of active fields of the festival the next field
+// is checked against
+func expectActiveFields(mockRepo *MockRepository, festivalID uuid.UUID, count int) {
+	mockRepo.On("CountActiveFields", mock.Anything, festivalID).Return(int64(count), nil).Once()
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestService_CreateField(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+	festivalID := uuid.New()
+	withKey := func(key string) interface{} {
+		return mock.MatchedBy(func(f *Field) bool { return f.Key == key })
+	}
+
+	expectActiveFields(mockRepo, festivalID, 0)
+	mockRepo.On("CreateField", mock.Anything, withKey("service")).Return(nil).Once()
+	field, err := service.CreateField(ctx, festivalID, CreateFieldRequest{
+		Key:     "service",
+		Label:   " Service ",
+		Type:    FieldTypeSelect,
+		Options: []string{" Lunch", "Dinner "},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Service", field.Label)
+	assert.Equal(t, []string{"Lunch", "Dinner"}, []string(field.Options))
+	assert.True(t, field.Active)
+
+	expectActiveFields(mockRepo, festivalID, 1)
+	mockRepo.On("CreateField", mock.Anything, withKey("service")).Return(ErrKeyTaken).Once()
+	_, err = service.CreateField(ctx, festivalID, CreateFieldRequest{Key: "service", Label: "Again", Type: FieldTypeText})
+	assert.ErrorIs(t, err, ErrKeyTaken)
+	_, err = service.CreateField(ctx, festivalID, CreateFieldRequest{Key: "Table No", Label: "Table", Type: FieldTypeNumber})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	expectActiveFields(mockRepo, festivalID, 1)
+	_, err = service.CreateField(ctx, festivalID, CreateFieldRequest{Key: "size", Label: "Size", Type: FieldTypeSelect, Options: []string{"S", "s"}})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+	expectActiveFields(mockRepo, festivalID, 1)
+	_, err = service.CreateField(ctx, festivalID, CreateFieldRequest{Key: "tokens", Label: "Tokens", Type: FieldTypeNumber, Min: float(5), Max: float(1)})
+	assert.ErrorIs(t, err, ErrInvalidBounds)
+	mockRepo.AssertNumberOfCalls(t, "CreateField", 2)
+
+	expectActiveFields(mockRepo, festivalID, MaxActiveFields)
+	_, err = service.CreateField(ctx, festivalID, CreateFieldRequest{Key: "one_more", Label: "One more", Type: FieldTypeText})
+	assert.ErrorIs(t, err, ErrTooManyFields)
+
+	inactive := false
+	mockRepo.On("GetField", mock.Anything, festivalID, field.ID).Return(field, nil).Once()
+	mockRepo.On("UpdateField", mock.Anything, mock.MatchedBy(func(f *Field) bool {
+		return f.ID == field.ID && !f.Active
+	})).Return(nil).Once()
+	_, err = service.UpdateField(ctx, festivalID, field.ID, UpdateFieldRequest{Active: &inactive})
+	require.NoError(t, err)
+
+	expectActiveFields(mockRepo, festivalID, MaxActiveFields-1)
+	mockRepo.On("CreateField", mock.Anything, withKey("one_more")).Return(nil).Once()
+	_, err = service.CreateField(ctx, festivalID, CreateFieldRequest{Key: "one_more", Label: "One more", Type: FieldTypeText})
+	assert.NoError(t, err, "inactive fields do not count")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidateValues(t *testing.T) {
+	fields := []Field{
+		{Key: "table", Label: "Table", Type: FieldTypeNumber, Required: true, Integer: true, Min: float(1), Max: float(40), PrintOnTicket: true},
+		{Key: "allergy", Label: "Allergy note", Type: FieldTypeText, MaxLength: 10},
+		{Key: "takeaway", Label: "Takeaway", Type: FieldTypeBoolean},
+		{Key: "service", Label: "Service", Type: FieldTypeSelect, Options: []string{"Lunch", "Dinner"}},
+	}
+
+	values, err := ValidateValues(fields, map[string]string{"service": "dinner", "table": " 12.0 ", "takeaway": "yes", "allergy": " "})
+	require.NoError(t, err)
+	assert.Equal(t, order.CustomFieldValues{
+		{Key: "table", Label: "Table", Value: "12", PrintOnTicket: true},
+		{Key: "takeaway", Label: "Takeaway", Value: "true"},
+		{Key: "service", Label: "Service", Value: "Dinner"},
+	}, values, "in the order of the fields, blank optional values left out")
+
+	cases := map[string]map[string]string{
+		"required":       {"allergy": "nuts"},
+		"not a number":   {"table": "twelve"},
+		"not whole":      {"table": "2.5"},
+		"below min":      {"table": "0"},
+		"above max":      {"table": "41"},
+		"too long":       {"table": "1", "allergy": "gluten and nuts"},
+		"not a boolean":  {"table": "1", "takeaway": "maybe"},
+		"not an option":  {"table": "1", "service": "Breakfast"},
+		"unknown field":  {"table": "1", "vip": "true"},
+		"infinite value": {"table": "Inf"},
+	}
+	for name, input := range cases {
+		_, err := ValidateValues(fields, input)
+		assert.ErrorIs(t, err, order.ErrInvalidCustomField, name)
+	}
+}
@@ -98,7 +98,7 @@ func (h *Handler) CreatePaymentIntent(c *gin.Context) {
 
 	var req CreatePaymentIntentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -163,7 +163,7 @@ func (h *Handler) CreateTicketPaymentIntent(c *gin.Context) {
 
 	var req CreateTicketPaymentIntentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -302,7 +302,7 @@ func (h *Handler) CreateConnectAccount(c *gin.Context) {
 
 	var req ConnectAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -483,7 +483,7 @@ func (h *Handler) CreateTransfer(c *gin.Context) {
 
 	var req CreateTransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
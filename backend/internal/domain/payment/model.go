@@ -14,12 +14,16 @@ type PaymentIntent struct {
 	UserID          uuid.UUID           `json:"userId" gorm:"type:uuid;not null;index"`
 	WalletID        uuid.UUID           `json:"walletId" gorm:"type:uuid;not null;index"`
 	Amount          int64               `json:"amount" gorm:"not null"` // Amount in cents
+	Kind            PaymentKind         `json:"kind" gorm:"not null;default:'TOP_UP'"`
+	TicketAmount    int64               `json:"ticketAmount" gorm:"default:0"` // Part of the amount paying for tickets
+	CreditAmount    int64               `json:"creditAmount" gorm:"default:0"` // Part of the amount credited to the wallet
 	Currency        string              `json:"currency" gorm:"default:'eur'"`
 	PlatformFee     int64               `json:"platformFee" gorm:"default:0"` // Platform fee in cents
 	Status          PaymentIntentStatus `json:"status" gorm:"default:'PENDING'"`
 	ClientSecret    string              `json:"-" gorm:"-"` // Only returned during creation, not stored
 	CustomerEmail   string              `json:"customerEmail,omitempty"`
 	FailureReason   string              `json:"failureReason,omitempty"`
+	CardLast4       string              `json:"cardLast4,omitempty"` // Last digits of the card charged, from the charge of the intent
 	CompletedAt     *time.Time          `json:"completedAt,omitempty"`
 	CreatedAt       time.Time           `json:"createdAt"`
 	UpdatedAt       time.Time           `json:"updatedAt"`
@@ -41,6 +45,17 @@ const (
 	PaymentIntentStatusCanceled           PaymentIntentStatus = "CANCELED"
 )
 
+// PaymentKind is what a payment intent pays for, which splits its revenue between
+// ticketing and cashless
+type PaymentKind string
+
+const (
+	PaymentKindTopUp   PaymentKind = "TOP_UP"  // Wallet top-up, all credited to the wallet
+	PaymentKindTicket  PaymentKind = "TICKET"  // Ticket purchase
+	PaymentKindBundle  PaymentKind = "BUNDLE"  // Entry bundle: a ticket and a wallet credit
+	PaymentKindPreSale PaymentKind = "PRESALE" // Pre-sale top-up, credited when the gates open
+)
+
 // StripeAccount represents a Stripe Connect account linked to a festival
 type StripeAccount struct {
 	ID               uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
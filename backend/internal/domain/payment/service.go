@@ -31,6 +31,18 @@ type FestivalInfo struct {
 	StripeAccountID string
 }
 
+// BundleFulfiller issues the ticket and the wallet credit of the entry bundle paid by a
+// payment intent, satisfied by *bundle.Service
+type BundleFulfiller interface {
+	FulfillPayment(ctx context.Context, stripeIntentID string) error
+}
+
+// PreSaleFulfiller records the payment of the pre-sale top-up paid by a payment intent,
+// satisfied by *presale.Service
+type PreSaleFulfiller interface {
+	FulfillPayment(ctx context.Context, stripeIntentID string) error
+}
+
 // Service handles payment business logic
 type Service struct {
 	db                 *gorm.DB
@@ -38,6 +50,8 @@ type Service struct {
 	walletService      WalletService
 	festivalService    FestivalService
 	ticketTypeProvider TicketTypeProvider
+	bundleFulfiller    BundleFulfiller
+	preSaleFulfiller   PreSaleFulfiller
 	baseURL            string
 }
 
@@ -60,6 +74,16 @@ func (s *Service) SetFestivalService(fs FestivalService) {
 	s.festivalService = fs
 }
 
+// SetBundleFulfiller sets the bundle service (to avoid circular dependency)
+func (s *Service) SetBundleFulfiller(bf BundleFulfiller) {
+	s.bundleFulfiller = bf
+}
+
+// SetPreSaleFulfiller sets the pre-sale service (to avoid circular dependency)
+func (s *Service) SetPreSaleFulfiller(pf PreSaleFulfiller) {
+	s.preSaleFulfiller = pf
+}
+
 // CreatePaymentIntent creates a new payment intent for wallet top-up
 func (s *Service) CreatePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, amount int64, currency string, email string) (*PaymentIntent, error) {
 	if amount < 100 {
@@ -103,6 +127,8 @@ func (s *Service) CreatePaymentIntent(ctx context.Context, festivalID, userID, w
 		UserID:         userID,
 		WalletID:       walletID,
 		Amount:         amount,
+		Kind:           PaymentKindTopUp,
+		CreditAmount:   amount,
 		Currency:       currency,
 		PlatformFee:    platformFee,
 		Status:         PaymentIntentStatusPending,
@@ -166,6 +192,8 @@ func (s *Service) ProcessWebhook(ctx context.Context, event *payment.WebhookEven
 		return s.handleTransferCreated(ctx, event)
 	case WebhookEventTransferFailed:
 		return s.handleTransferFailed(ctx, event)
+	case WebhookEventChargeSucceeded:
+		return s.handleChargeSucceeded(ctx, event)
 	default:
 		log.Debug().Str("event_type", event.Type).Msg("Unhandled webhook event type")
 		return nil
@@ -198,6 +226,43 @@ func (s *Service) handlePaymentIntentSucceeded(ctx context.Context, event *payme
 		return fmt.Errorf("failed to update payment intent: %w", err)
 	}
 
+	// Entry bundles issue their ticket and credit their wallet together
+	if pi.Kind == PaymentKindBundle {
+		if s.bundleFulfiller == nil {
+			return fmt.Errorf("no bundle service to fulfill payment intent %s", pi.StripeIntentID)
+		}
+		if err := s.bundleFulfiller.FulfillPayment(ctx, pi.StripeIntentID); err != nil {
+			log.Error().Err(err).
+				Str("payment_intent_id", pi.ID.String()).
+				Msg("Failed to fulfill entry bundle after successful payment")
+			return fmt.Errorf("failed to fulfill entry bundle: %w", err)
+		}
+		log.Info().
+			Str("payment_intent_id", pi.ID.String()).
+			Int64("ticket_amount", pi.TicketAmount).
+			Int64("credit_amount", pi.CreditAmount).
+			Msg("Payment succeeded and entry bundle fulfilled")
+		return nil
+	}
+
+	// Pre-sale top-ups are held until the gates open, the pre-sale service credits them
+	if pi.Kind == PaymentKindPreSale {
+		if s.preSaleFulfiller == nil {
+			return fmt.Errorf("no pre-sale service to fulfill payment intent %s", pi.StripeIntentID)
+		}
+		if err := s.preSaleFulfiller.FulfillPayment(ctx, pi.StripeIntentID); err != nil {
+			log.Error().Err(err).
+				Str("payment_intent_id", pi.ID.String()).
+				Msg("Failed to record pre-sale top-up after successful payment")
+			return fmt.Errorf("failed to fulfill pre-sale top-up: %w", err)
+		}
+		log.Info().
+			Str("payment_intent_id", pi.ID.String()).
+			Int64("amount", pi.Amount).
+			Msg("Payment succeeded for pre-sale top-up")
+		return nil
+	}
+
 	// Credit wallet
 	if s.walletService != nil {
 		if err := s.walletService.TopUpFromPayment(ctx, pi.WalletID, pi.Amount, pi.StripeIntentID); err != nil {
@@ -217,6 +282,35 @@ func (s *Service) handlePaymentIntentSucceeded(ctx context.Context, event *payme
 	return nil
 }
 
+// handleChargeSucceeded keeps the last digits of the card charged for a payment intent,
+// which support staff search transactions by
+func (s *Service) handleChargeSucceeded(ctx context.Context, event *payment.WebhookEvent) error {
+	var charge struct {
+		PaymentIntent        string `json:"payment_intent"`
+		PaymentMethodDetails struct {
+			Card *struct {
+				Last4 string `json:"last4"`
+			} `json:"card"`
+		} `json:"payment_method_details"`
+	}
+	if err := json.Unmarshal(event.Data, &charge); err != nil {
+		return fmt.Errorf("failed to parse charge: %w", err)
+	}
+
+	card := charge.PaymentMethodDetails.Card
+	if charge.PaymentIntent == "" || card == nil || card.Last4 == "" {
+		return nil
+	}
+
+	err := s.db.WithContext(ctx).Model(&PaymentIntent{}).
+		Where("stripe_intent_id = ?", charge.PaymentIntent).
+		Update("card_last4", card.Last4).Error
+	if err != nil {
+		return fmt.Errorf("failed to save card of payment intent: %w", err)
+	}
+	return nil
+}
+
 func (s *Service) handlePaymentIntentFailed(ctx context.Context, event *payment.WebhookEvent) error {
 	piData, err := payment.ParsePaymentIntentFromWebhook(event.Data)
 	if err != nil {
@@ -660,6 +754,8 @@ func (s *Service) CreateTicketPaymentIntent(ctx context.Context, festivalID, use
 		UserID:         userID,
 		WalletID:       uuid.Nil, // No wallet for ticket purchase
 		Amount:         totalAmount,
+		Kind:           PaymentKindTicket,
+		TicketAmount:   totalAmount,
 		Currency:       currency,
 		PlatformFee:    platformFee,
 		Status:         PaymentIntentStatusPending,
@@ -675,3 +771,182 @@ func (s *Service) CreateTicketPaymentIntent(ctx context.Context, festivalID, use
 
 	return pi, nil
 }
+
+// CreateBundlePaymentIntent creates the payment intent of an entry bundle: one payment
+// for a ticket and a wallet credit, recorded with how much pays for each. It returns the
+// Stripe ID of the intent and the client secret the app confirms it with.
+func (s *Service) CreateBundlePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, ticketAmount, creditAmount int64, email string, metadata map[string]string) (string, string, error) {
+	amount := ticketAmount + creditAmount
+	if amount < 100 {
+		return "", "", errors.New("MINIMUM_AMOUNT", "Minimum amount is 100 cents (1 EUR)")
+	}
+	currency := "eur"
+
+	// Get festival Stripe account if connected
+	var connectedAccount string
+	stripeAcct, err := s.GetStripeAccountByFestival(ctx, festivalID)
+	if err == nil && stripeAcct != nil && stripeAcct.ChargesEnabled {
+		connectedAccount = stripeAcct.StripeAccountID
+	}
+
+	intentMetadata := map[string]string{
+		"type":          "bundle_purchase",
+		"ticket_amount": fmt.Sprintf("%d", ticketAmount),
+		"credit_amount": fmt.Sprintf("%d", creditAmount),
+	}
+	for key, value := range metadata {
+		intentMetadata[key] = value
+	}
+
+	result, err := s.stripeClient.CreatePaymentIntent(ctx, payment.CreatePaymentIntentParams{
+		Amount:           amount,
+		Currency:         currency,
+		FestivalID:       festivalID,
+		UserID:           userID,
+		WalletID:         walletID,
+		Description:      "Entry bundle: ticket and wallet credit",
+		CustomerEmail:    email,
+		ConnectedAccount: connectedAccount,
+		Metadata:         intentMetadata,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	pi := &PaymentIntent{
+		ID:             uuid.New(),
+		StripeIntentID: result.PaymentIntentID,
+		FestivalID:     festivalID,
+		UserID:         userID,
+		WalletID:       walletID,
+		Amount:         amount,
+		Kind:           PaymentKindBundle,
+		TicketAmount:   ticketAmount,
+		CreditAmount:   creditAmount,
+		Currency:       currency,
+		PlatformFee:    CalculatePlatformFee(amount),
+		Status:         PaymentIntentStatusPending,
+		CustomerEmail:  email,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(pi).Error; err != nil {
+		return "", "", fmt.Errorf("failed to save payment intent: %w", err)
+	}
+
+	return result.PaymentIntentID, result.ClientSecret, nil
+}
+
+// CreatePreSalePaymentIntent creates the payment intent of a pre-sale top-up, paid now and
+// credited to the wallet when the festival gates open. It returns the Stripe ID of the
+// intent and the client secret the app confirms it with.
+func (s *Service) CreatePreSalePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, amount int64, email string, metadata map[string]string) (string, string, error) {
+	if amount < 100 {
+		return "", "", errors.New("MINIMUM_AMOUNT", "Minimum amount is 100 cents (1 EUR)")
+	}
+	currency := "eur"
+
+	// Get festival Stripe account if connected
+	var connectedAccount string
+	stripeAcct, err := s.GetStripeAccountByFestival(ctx, festivalID)
+	if err == nil && stripeAcct != nil && stripeAcct.ChargesEnabled {
+		connectedAccount = stripeAcct.StripeAccountID
+	}
+
+	intentMetadata := map[string]string{
+		"type": "presale_topup",
+	}
+	for key, value := range metadata {
+		intentMetadata[key] = value
+	}
+
+	result, err := s.stripeClient.CreatePaymentIntent(ctx, payment.CreatePaymentIntentParams{
+		Amount:           amount,
+		Currency:         currency,
+		FestivalID:       festivalID,
+		UserID:           userID,
+		WalletID:         walletID,
+		Description:      "Wallet pre-sale top-up",
+		CustomerEmail:    email,
+		ConnectedAccount: connectedAccount,
+		Metadata:         intentMetadata,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	pi := &PaymentIntent{
+		ID:             uuid.New(),
+		StripeIntentID: result.PaymentIntentID,
+		FestivalID:     festivalID,
+		UserID:         userID,
+		WalletID:       walletID,
+		Amount:         amount,
+		Kind:           PaymentKindPreSale,
+		CreditAmount:   amount,
+		Currency:       currency,
+		PlatformFee:    CalculatePlatformFee(amount),
+		Status:         PaymentIntentStatusPending,
+		CustomerEmail:  email,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(pi).Error; err != nil {
+		return "", "", fmt.Errorf("failed to save payment intent: %w", err)
+	}
+
+	return result.PaymentIntentID, result.ClientSecret, nil
+}
+
+// RefundBundlePayment refunds part or all of the payment of an entry bundle and records
+// the refund. The intent stays succeeded: its ticket and credit were unwound by the bundle
+// service, which records the split of the refund.
+func (s *Service) RefundBundlePayment(ctx context.Context, stripeIntentID string, amount int64, reason string) (string, error) {
+	pi, err := s.GetPaymentIntentByStripeID(ctx, stripeIntentID)
+	if err != nil {
+		return "", err
+	}
+	if pi.Status != PaymentIntentStatusSucceeded {
+		return "", errors.New("INVALID_STATUS", "Can only refund succeeded payments")
+	}
+	if amount <= 0 || amount > pi.Amount {
+		amount = pi.Amount
+	}
+
+	result, err := s.stripeClient.CreateRefund(ctx, payment.CreateRefundParams{
+		PaymentIntentID: pi.StripeIntentID,
+		Amount:          amount,
+		Reason:          "requested_by_customer",
+		Metadata: map[string]string{
+			"local_payment_intent_id": pi.ID.String(),
+			"festival_id":             pi.FestivalID.String(),
+			"reason":                  reason,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	refund := &Refund{
+		ID:              uuid.New(),
+		PaymentIntentID: pi.ID,
+		StripeRefundID:  result.RefundID,
+		Amount:          result.Amount,
+		Currency:        result.Currency,
+		Status:          RefundStatus(result.Status),
+		Reason:          reason,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(refund).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to save refund record locally")
+		// Don't fail the operation - the Stripe refund was successful
+	}
+
+	log.Info().
+		Str("payment_intent_id", pi.ID.String()).
+		Str("refund_id", result.RefundID).
+		Int64("amount", amount).
+		Msg("Entry bundle payment refunded")
+
+	return result.RefundID, nil
+}
@@ -0,0 +1,480 @@
+package posdevice
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped pairing and device management routes
+// of the organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	pairings := r.Group("/pos-pairings")
+	{
+		pairings.GET("", h.ListPairings)
+		pairings.POST("", h.CreatePairing)
+		pairings.GET("/:pairingId", h.GetPairing)
+		pairings.DELETE("/:pairingId", h.CancelPairing)
+	}
+
+	devices := r.Group("/pos-devices")
+	{
+		devices.GET("", h.ListDevices)
+		devices.GET("/:deviceId", h.GetDevice)
+		devices.DELETE("/:deviceId", h.Unpair)
+	}
+}
+
+// RegisterDeviceRoutes registers the pairing exchange and the routes of the paired
+// POS terminals, authenticated with their device token. The verify handlers check the
+// signature of the requests, except the one fetching the signing key.
+func (h *Handler) RegisterDeviceRoutes(r *gin.RouterGroup, verify ...gin.HandlerFunc) {
+	r.POST("/pair", h.Exchange)
+
+	device := r.Group("")
+	device.Use(h.Authenticate)
+	device.GET("/signing-key", h.SigningKey)
+
+	signed := device.Group("")
+	signed.Use(verify...)
+	{
+		signed.GET("/session", h.Session)
+		signed.DELETE("/session", h.UnpairSelf)
+		signed.GET("/sequences", h.Sequences)
+	}
+}
+
+// ListPairings lists the pairing codes that can still be scanned
+// @Summary List POS pairing codes
+// @Description List the unused pairing codes of the festival that have not expired
+// @Tags pos-devices
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Pairing} "Pairing codes"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-pairings [get]
+func (h *Handler) ListPairings(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	pairings, err := h.service.ListPairings(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, pairings)
+}
+
+// CreatePairing creates a pairing QR code for a stand
+// @Summary Create POS pairing code
+// @Description Create a single-use pairing code for a stand, valid 5 minutes by default. The code and its QR code are only returned once.
+// @Tags pos-devices
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreatePairingRequest true "Pairing"
+// @Success 201 {object} response.Response{data=PairingWithCode} "Pairing code"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-pairings [post]
+func (h *Handler) CreatePairing(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreatePairingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	pairing, err := h.service.CreatePairing(c.Request.Context(), festivalID, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, pairing)
+}
+
+// GetPairing returns a pairing code
+// @Summary Get POS pairing code
+// @Description Get a pairing code; the dashboard polls it to know when a device scanned it
+// @Tags pos-devices
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param pairingId path string true "Pairing ID" format(uuid)
+// @Success 200 {object} response.Response{data=Pairing} "Pairing code"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Pairing not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-pairings/{pairingId} [get]
+func (h *Handler) GetPairing(c *gin.Context) {
+	festivalID, pairingID, ok := pathParams(c, "pairingId", "pairing")
+	if !ok {
+		return
+	}
+
+	pairing, err := h.service.GetPairing(c.Request.Context(), festivalID, pairingID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, pairing)
+}
+
+// CancelPairing cancels a pairing code
+// @Summary Cancel POS pairing code
+// @Description Delete a pairing code before a device scans it
+// @Tags pos-devices
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param pairingId path string true "Pairing ID" format(uuid)
+// @Success 204 "Cancelled"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Pairing not found"
+// @Failure 409 {object} response.ErrorResponse "Pairing already used"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-pairings/{pairingId} [delete]
+func (h *Handler) CancelPairing(c *gin.Context) {
+	festivalID, pairingID, ok := pathParams(c, "pairingId", "pairing")
+	if !ok {
+		return
+	}
+
+	if err := h.service.CancelPairing(c.Request.Context(), festivalID, pairingID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListDevices lists the POS devices of the festival
+// @Summary List POS devices
+// @Description List the paired POS terminals with whether they were seen in the last 5 minutes
+// @Tags pos-devices
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the devices of this stand" format(uuid)
+// @Param includeUnpaired query bool false "Include the unpaired devices"
+// @Success 200 {object} response.Response{data=[]DeviceResponse} "POS devices"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-devices [get]
+func (h *Handler) ListDevices(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return
+	}
+	includeUnpaired, _ := strconv.ParseBool(c.Query("includeUnpaired"))
+
+	devices, err := h.service.ListDevices(c.Request.Context(), festivalID, ListDevicesQuery{
+		StandID:         standID,
+		IncludeUnpaired: includeUnpaired,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, devices)
+}
+
+// GetDevice returns a POS device
+// @Summary Get POS device
+// @Tags pos-devices
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param deviceId path string true "Device ID" format(uuid)
+// @Success 200 {object} response.Response{data=DeviceResponse} "POS device"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Device not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-devices/{deviceId} [get]
+func (h *Handler) GetDevice(c *gin.Context) {
+	festivalID, deviceID, ok := pathParams(c, "deviceId", "device")
+	if !ok {
+		return
+	}
+
+	device, err := h.service.GetDevice(c.Request.Context(), festivalID, deviceID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, device)
+}
+
+// Unpair unpairs a POS device remotely
+// @Summary Unpair POS device
+// @Description Revoke the device token of a POS terminal at once. Its next request fails with DEVICE_UNPAIRED and the app returns to pairing.
+// @Tags pos-devices
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param deviceId path string true "Device ID" format(uuid)
+// @Success 200 {object} response.Response{data=DeviceResponse} "Unpaired device"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Device not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/pos-devices/{deviceId} [delete]
+func (h *Handler) Unpair(c *gin.Context) {
+	festivalID, deviceID, ok := pathParams(c, "deviceId", "device")
+	if !ok {
+		return
+	}
+
+	device, err := h.service.Unpair(c.Request.Context(), festivalID, deviceID, currentUser(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, device)
+}
+
+// Exchange pairs a POS terminal with a scanned pairing code
+// @Summary Pair POS device
+// @Description Exchange the code of a scanned pairing QR code for a device token bound to the stand. The token is only returned once.
+// @Tags pos-devices
+// @Accept json
+// @Produce json
+// @Param request body ExchangeRequest true "Pairing code and device details"
+// @Success 201 {object} response.Response{data=PairedDevice} "Paired device"
+// @Failure 400 {object} response.ErrorResponse "Invalid, expired or used pairing code"
+// @Router /pos-device/pair [post]
+func (h *Handler) Exchange(c *gin.Context) {
+	var req ExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	device, err := h.service.Exchange(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, device)
+}
+
+// Session returns the paired device making the request
+// @Summary Get POS device session
+// @Description Get the device and stand a POS terminal is paired to
+// @Tags pos-devices
+// @Produce json
+// @Success 200 {object} response.Response{data=Session} "Session"
+// @Failure 401 {object} response.ErrorResponse "Invalid token or device unpaired"
+// @Security DeviceToken
+// @Router /pos-device/session [get]
+func (h *Handler) Session(c *gin.Context) {
+	session, err := h.service.Session(c.Request.Context(), CurrentDevice(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, session)
+}
+
+// SigningKey returns the key the device signs its requests with
+// @Summary Get POS device signing key
+// @Description Get the HMAC key the POS terminal signs its requests with, to protect them from replay. Fetch it again when a request fails with SIGNATURE_INVALID, the key changes when the server secrets are rotated.
+// @Tags pos-devices
+// @Produce json
+// @Success 200 {object} response.Response{data=SigningKey} "Signing key"
+// @Failure 401 {object} response.ErrorResponse "Invalid token or device unpaired"
+// @Failure 404 {object} response.ErrorResponse "Request signing not configured"
+// @Security DeviceToken
+// @Router /pos-device/signing-key [get]
+func (h *Handler) SigningKey(c *gin.Context) {
+	key, err := h.service.SigningKey(CurrentDevice(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	response.OK(c, key)
+}
+
+// Sequences returns the change counters of the festival of the device
+// @Summary Get POS change sequences
+// @Description Get the counters of the wallet, price and product changes of the festival. Each only increases; when one differs from the value the terminal loaded its cache at, the cache is stale and should be refreshed before accepting a transaction offline.
+// @Tags pos-devices
+// @Produce json
+// @Success 200 {object} response.Response{data=Sequences} "Change sequences"
+// @Failure 401 {object} response.ErrorResponse "Invalid token or device unpaired"
+// @Failure 404 {object} response.ErrorResponse "Change sequences not configured"
+// @Security DeviceToken
+// @Router /pos-device/sequences [get]
+func (h *Handler) Sequences(c *gin.Context) {
+	sequences, err := h.service.Sequences(c.Request.Context(), CurrentDevice(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	response.OK(c, sequences)
+}
+
+// UnpairSelf unpairs the device making the request
+// @Summary Unpair from the device
+// @Description Unpair the POS terminal making the request, e.g. before it is reset
+// @Tags pos-devices
+// @Success 204 "Unpaired"
+// @Failure 401 {object} response.ErrorResponse "Invalid token or device unpaired"
+// @Security DeviceToken
+// @Router /pos-device/session [delete]
+func (h *Handler) UnpairSelf(c *gin.Context) {
+	if err := h.service.UnpairSelf(c.Request.Context(), CurrentDevice(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Authenticate resolves the POS device of the bearer device token. It sets the
+// festival and stand of the device in the context, so festival-scoped routes can be
+// served to paired terminals.
+func (h *Handler) Authenticate(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	device, err := h.service.AuthenticateDevice(c.Request.Context(), token)
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set("pos_device", device)
+	c.Set(DeviceIDKey, device.ID.String())
+	c.Set("festival_id", device.FestivalID.String())
+	c.Set("stand_id", device.StandID.String())
+	c.Next()
+}
+
+// IdentifyDevice resolves the POS device of the token sent in DeviceTokenHeader on the
+// staff payment routes, so that the requests of the terminal are verified against its
+// signing key. Requests without it go on without a device, and are refused by
+// SignedRequests once signing is required.
+func (h *Handler) IdentifyDevice(c *gin.Context) {
+	token := c.GetHeader(DeviceTokenHeader)
+	if token == "" {
+		c.Next()
+		return
+	}
+
+	device, err := h.service.AuthenticateDevice(c.Request.Context(), token)
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set("pos_device", device)
+	c.Set(DeviceIDKey, device.ID.String())
+	c.Next()
+}
+
+// CurrentDevice returns the device set by Authenticate or IdentifyDevice
+func CurrentDevice(c *gin.Context) *Device {
+	return c.MustGet("pos_device").(*Device)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func pathParams(c *gin.Context, param, name string) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid "+name+" ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, id, true
+}
+
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid "+name, nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidDeviceToken):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, ErrDeviceUnpaired):
+		response.SendError(c, response.NewStandardError(http.StatusUnauthorized, "DEVICE_UNPAIRED", err.Error(), nil))
+	case errors.Is(err, ErrPairingNotFound):
+		response.NotFound(c, "Pairing not found")
+	case errors.Is(err, ErrDeviceNotFound):
+		response.NotFound(c, "POS device not found")
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	case errors.Is(err, ErrSigningDisabled):
+		response.NotFound(c, "Request signing is not configured")
+	case errors.Is(err, ErrSequencesDisabled):
+		response.NotFound(c, "Change sequences are not configured")
+	case errors.Is(err, ErrInvalidTTL):
+		response.BadRequest(c, "VALIDATION_ERROR", err.Error(), nil)
+	case errors.Is(err, ErrInvalidPairingCode):
+		response.BadRequest(c, "INVALID_PAIRING_CODE", err.Error(), nil)
+	case errors.Is(err, ErrPairingUsed):
+		response.Conflict(c, "PAIRING_USED", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,148 @@
+package posdevice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// POS device errors
+var (
+	ErrPairingNotFound    = errors.New("pairing not found")
+	ErrDeviceNotFound     = errors.New("POS device not found")
+	ErrStandNotFound      = errors.New("stand not found")
+	ErrInvalidTTL         = errors.New("ttlSeconds must be between 60 and 1800")
+	ErrInvalidPairingCode = errors.New("invalid, expired or already used pairing code")
+	ErrPairingUsed        = errors.New("pairing code was already used")
+	ErrInvalidDeviceToken = errors.New("invalid POS device token")
+	ErrDeviceUnpaired     = errors.New("POS device was unpaired")
+	ErrSigningDisabled    = errors.New("request signing is not configured")
+	ErrSequencesDisabled  = errors.New("change sequences are not configured")
+)
+
+// Pairing and device credentials
+const (
+	PairingCodePrefix = "pair_"
+	DeviceTokenPrefix = "pos_"
+	PairingURI        = "festivals-pos://pair" // Scheme the POS app opens from the scanned QR code
+	DefaultPairingTTL = 5 * time.Minute
+	MinPairingTTL     = time.Minute
+	MaxPairingTTL     = 30 * time.Minute
+	OnlineWindow      = 5 * time.Minute // Devices seen within this window are shown online
+	touchInterval     = time.Minute     // LastSeenAt is written at most once per interval
+
+	// Context key holding the ID of the authenticated device, the client ID its
+	// signed requests are verified with
+	DeviceIDKey = "pos_device_id"
+
+	// Header carrying the device token on the staff payment routes, sent by the
+	// terminal along the token of the signed-in staff member
+	DeviceTokenHeader = "X-POS-Device-Token"
+)
+
+// Pairing is a single-use code an organizer shows as a QR code on the dashboard. The
+// POS terminal scanning it before it expires is paired to the stand.
+type Pairing struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	DeviceName string     `json:"deviceName,omitempty"` // Name given to the paired device
+	CodeHash   string     `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedBy  *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	ExpiresAt  time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt     *time.Time `json:"usedAt,omitempty"`
+	DeviceID   *uuid.UUID `json:"deviceId,omitempty" gorm:"type:uuid"` // Device paired with the code
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (Pairing) TableName() string {
+	return "pos_pairings"
+}
+
+// PairingWithCode is returned once when a pairing is created
+type PairingWithCode struct {
+	Pairing
+	Code      string `json:"code"`
+	QRPayload string `json:"qrPayload"` // Content of the QR code
+	QRCode    string `json:"qrCode"`    // PNG data URI to display
+}
+
+// Device is a POS terminal paired to a stand. It authenticates with its device token
+// until it is unpaired.
+type Device struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID     uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	Name        string     `json:"name" gorm:"not null"`
+	Platform    string     `json:"platform,omitempty"`
+	AppVersion  string     `json:"appVersion,omitempty"`
+	TokenHash   string     `json:"-" gorm:"not null;uniqueIndex"`
+	TokenPrefix string     `json:"tokenPrefix"`                         // Identifies the device token without revealing it
+	PairedBy    *uuid.UUID `json:"pairedBy,omitempty" gorm:"type:uuid"` // Organizer who created the pairing
+	PairedAt    time.Time  `json:"pairedAt" gorm:"not null"`
+	LastSeenAt  *time.Time `json:"lastSeenAt,omitempty"`
+	UnpairedAt  *time.Time `json:"unpairedAt,omitempty"`
+	UnpairedBy  *uuid.UUID `json:"unpairedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Device) TableName() string {
+	return "pos_devices"
+}
+
+// DeviceResponse is a device with whether it was seen recently
+type DeviceResponse struct {
+	Device
+	Online bool `json:"online"`
+}
+
+// StandInfo is what pairing needs of a stand
+type StandInfo struct {
+	ID           uuid.UUID `json:"id"`
+	FestivalID   uuid.UUID `json:"festivalId"`
+	Name         string    `json:"name"`
+	FestivalName string    `json:"festivalName"`
+}
+
+// Session is what a paired device knows of itself
+type Session struct {
+	Device Device    `json:"device"`
+	Stand  StandInfo `json:"stand"`
+}
+
+// PairedDevice is returned once to the device when it exchanges a pairing code
+type PairedDevice struct {
+	Session
+	DeviceToken string `json:"deviceToken"`
+}
+
+// SigningKey is the key a device signs its requests with. It changes when the server
+// secrets are rotated, the device then fetches it again.
+type SigningKey struct {
+	Key       string   `json:"key"` // Base64 HMAC key
+	Algorithm string   `json:"algorithm"`
+	Headers   []string `json:"headers"` // Signature, timestamp and nonce headers
+}
+
+// CreatePairingRequest is the request to create a pairing QR code
+type CreatePairingRequest struct {
+	StandID    uuid.UUID `json:"standId" binding:"required"`
+	DeviceName string    `json:"deviceName" binding:"max=100"`
+	TTLSeconds int       `json:"ttlSeconds,omitempty"` // DefaultPairingTTL when zero
+}
+
+// ExchangeRequest is sent by a POS terminal after scanning a pairing QR code
+type ExchangeRequest struct {
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"max=100"` // Used when the pairing has no name
+	Platform   string `json:"platform" binding:"max=50"`
+	AppVersion string `json:"appVersion" binding:"max=50"`
+}
+
+// ListDevicesQuery filters the devices of a festival
+type ListDevicesQuery struct {
+	StandID         *uuid.UUID
+	IncludeUnpaired bool
+}
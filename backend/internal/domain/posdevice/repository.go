@@ -0,0 +1,187 @@
+package posdevice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreatePairing(ctx context.Context, pairing *Pairing) error
+	GetPairing(ctx context.Context, festivalID, id uuid.UUID) (*Pairing, error)
+	// ListOpenPairings lists the unused pairings of a festival expiring after now
+	ListOpenPairings(ctx context.Context, festivalID uuid.UUID, now time.Time) ([]Pairing, error)
+	DeletePairing(ctx context.Context, id uuid.UUID) error
+	// ClaimPairing marks the pairing of a code as used by deviceID, unless it is used
+	// or expired at now; it returns nil when no pairing was claimed
+	ClaimPairing(ctx context.Context, codeHash string, deviceID uuid.UUID, now time.Time) (*Pairing, error)
+
+	CreateDevice(ctx context.Context, device *Device) error
+	GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error)
+	GetDeviceByTokenHash(ctx context.Context, tokenHash string) (*Device, error)
+	ListDevices(ctx context.Context, festivalID uuid.UUID, query ListDevicesQuery) ([]Device, error)
+	UpdateDevice(ctx context.Context, device *Device) error
+	TouchDevice(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error)
+	// GetWalletFestivalID returns the festival of a wallet, nil when it does not exist
+	GetWalletFestivalID(ctx context.Context, walletID uuid.UUID) (*uuid.UUID, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreatePairing(ctx context.Context, pairing *Pairing) error {
+	if err := r.db.WithContext(ctx).Create(pairing).Error; err != nil {
+		return fmt.Errorf("failed to create pairing: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetPairing(ctx context.Context, festivalID, id uuid.UUID) (*Pairing, error) {
+	var pairing Pairing
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&pairing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pairing: %w", err)
+	}
+	return &pairing, nil
+}
+
+func (r *repository) ListOpenPairings(ctx context.Context, festivalID uuid.UUID, now time.Time) ([]Pairing, error) {
+	var pairings []Pairing
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND used_at IS NULL AND expires_at > ?", festivalID, now).
+		Order("created_at DESC").
+		Find(&pairings).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pairings: %w", err)
+	}
+	return pairings, nil
+}
+
+func (r *repository) DeletePairing(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&Pairing{}).Error; err != nil {
+		return fmt.Errorf("failed to delete pairing: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ClaimPairing(ctx context.Context, codeHash string, deviceID uuid.UUID, now time.Time) (*Pairing, error) {
+	var pairings []Pairing
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE public.pos_pairings
+		SET used_at = ?, device_id = ?
+		WHERE code_hash = ? AND used_at IS NULL AND expires_at > ?
+		RETURNING *`,
+		now, deviceID, codeHash, now).Scan(&pairings).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pairing: %w", err)
+	}
+	if len(pairings) == 0 {
+		return nil, nil
+	}
+	return &pairings[0], nil
+}
+
+func (r *repository) CreateDevice(ctx context.Context, device *Device) error {
+	if err := r.db.WithContext(ctx).Create(device).Error; err != nil {
+		return fmt.Errorf("failed to create POS device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error) {
+	var device Device
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&device).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get POS device: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *repository) GetDeviceByTokenHash(ctx context.Context, tokenHash string) (*Device, error) {
+	var device Device
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&device).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get POS device: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *repository) ListDevices(ctx context.Context, festivalID uuid.UUID, query ListDevicesQuery) ([]Device, error) {
+	var devices []Device
+	db := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if query.StandID != nil {
+		db = db.Where("stand_id = ?", *query.StandID)
+	}
+	if !query.IncludeUnpaired {
+		db = db.Where("unpaired_at IS NULL")
+	}
+	if err := db.Order("paired_at DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list POS devices: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *repository) UpdateDevice(ctx context.Context, device *Device) error {
+	if err := r.db.WithContext(ctx).Save(device).Error; err != nil {
+		return fmt.Errorf("failed to update POS device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) TouchDevice(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Device{}).Where("id = ?", id).Update("last_seen_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to touch POS device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	var infos []StandInfo
+	err := r.db.WithContext(ctx).
+		Table("public.stands s").
+		Select("s.id, s.festival_id, s.name, f.name AS festival_name").
+		Joins("JOIN public.festivals f ON f.id = s.festival_id").
+		Where("s.id = ?", standID).
+		Limit(1).
+		Scan(&infos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	return &infos[0], nil
+}
+
+func (r *repository) GetWalletFestivalID(ctx context.Context, walletID uuid.UUID) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("public.wallets").Where("id = ?", walletID).Limit(1).Pluck("festival_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet festival: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
@@ -0,0 +1,104 @@
+package posdevice
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreatePairing(ctx context.Context, pairing *Pairing) error {
+	args := m.Called(ctx, pairing)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPairing(ctx context.Context, festivalID, id uuid.UUID) (*Pairing, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pairing), args.Error(1)
+}
+
+func (m *MockRepository) ListOpenPairings(ctx context.Context, festivalID uuid.UUID, now time.Time) ([]Pairing, error) {
+	args := m.Called(ctx, festivalID, now)
+	return args.Get(0).([]Pairing), args.Error(1)
+}
+
+func (m *MockRepository) DeletePairing(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimPairing(ctx context.Context, codeHash string, deviceID uuid.UUID, now time.Time) (*Pairing, error) {
+	args := m.Called(ctx, codeHash, deviceID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pairing), args.Error(1)
+}
+
+func (m *MockRepository) CreateDevice(ctx context.Context, device *Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Device), args.Error(1)
+}
+
+func (m *MockRepository) GetDeviceByTokenHash(ctx context.Context, tokenHash string) (*Device, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Device), args.Error(1)
+}
+
+func (m *MockRepository) ListDevices(ctx context.Context, festivalID uuid.UUID, query ListDevicesQuery) ([]Device, error) {
+	args := m.Called(ctx, festivalID, query)
+	return args.Get(0).([]Device), args.Error(1)
+}
+
+func (m *MockRepository) UpdateDevice(ctx context.Context, device *Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchDevice(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	args := m.Called(ctx, standID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandInfo), args.Error(1)
+}
+
+func (m *MockRepository) GetWalletFestivalID(ctx context.Context, walletID uuid.UUID) (*uuid.UUID, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uuid.UUID), args.Error(1)
+}
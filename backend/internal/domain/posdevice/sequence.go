@@ -0,0 +1,174 @@
+package posdevice
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Stream is a kind of data the POS terminals cache, whose changes are counted per
+// festival
+type Stream string
+
+const (
+	StreamWallets  Stream = "wallets"
+	StreamPrices   Stream = "prices"
+	StreamProducts Stream = "products"
+)
+
+// sequencesKeyPrefix prefixes the Redis hash holding the counters of a festival, one
+// field per stream
+const sequencesKeyPrefix = "posdevice:sequences:"
+
+// Sequences are the change counters of a festival. A terminal keeps the sequences its
+// cache was loaded at and knows the cache is stale as soon as one of them differs.
+type Sequences struct {
+	FestivalID uuid.UUID `json:"festivalId"`
+	Wallets    int64     `json:"wallets"`
+	Prices     int64     `json:"prices"`
+	Products   int64     `json:"products"`
+	At         time.Time `json:"at"`
+}
+
+// SequenceStore keeps the change counters of the festivals
+type SequenceStore interface {
+	// Increment increments the counter of a stream of a festival and returns it
+	Increment(ctx context.Context, festivalID uuid.UUID, stream Stream) (int64, error)
+	// Get returns the counters of a festival, without the streams that never changed
+	Get(ctx context.Context, festivalID uuid.UUID) (map[Stream]int64, error)
+}
+
+type sequenceStore struct {
+	redisClient *redis.Client
+}
+
+// NewSequenceStore creates change counters stored in Redis, read with a single command
+func NewSequenceStore(redisClient *redis.Client) SequenceStore {
+	return &sequenceStore{redisClient: redisClient}
+}
+
+func (r *sequenceStore) Increment(ctx context.Context, festivalID uuid.UUID, stream Stream) (int64, error) {
+	seq, err := r.redisClient.HIncrBy(ctx, sequencesKeyPrefix+festivalID.String(), string(stream), 1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s sequence: %w", stream, err)
+	}
+	return seq, nil
+}
+
+func (r *sequenceStore) Get(ctx context.Context, festivalID uuid.UUID) (map[Stream]int64, error) {
+	fields, err := r.redisClient.HGetAll(ctx, sequencesKeyPrefix+festivalID.String()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sequences: %w", err)
+	}
+
+	sequences := make(map[Stream]int64, len(fields))
+	for field, value := range fields {
+		seq, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s sequence: %w", field, err)
+		}
+		sequences[Stream(field)] = seq
+	}
+	return sequences, nil
+}
+
+// Sequencer counts the wallet, price and product changes of each festival, which the
+// POS terminals compare to their cache before accepting a transaction they could not
+// check online. The wallet, product and pricing services report their changes once
+// saved; a counter failing to move is logged and never fails the change.
+type Sequencer struct {
+	store SequenceStore
+	repo  Repository
+	now   func() time.Time
+
+	// The festival of a wallet or stand never changes, so it is only looked up once
+	festivals sync.Map
+}
+
+// NewSequencer creates a sequencer, resolving the festivals of wallets and stands
+// with the repository
+func NewSequencer(store SequenceStore, repo Repository) *Sequencer {
+	return &Sequencer{store: store, repo: repo, now: time.Now}
+}
+
+// WalletChanged counts a change of the balance or status of a wallet
+func (s *Sequencer) WalletChanged(ctx context.Context, walletID uuid.UUID) {
+	festivalID, err := s.festivalOf(ctx, walletID, s.repo.GetWalletFestivalID)
+	s.bump(ctx, festivalID, StreamWallets, err)
+}
+
+// ProductsChanged counts a product of a stand being created, edited or removed
+func (s *Sequencer) ProductsChanged(ctx context.Context, standID uuid.UUID) {
+	festivalID, err := s.festivalOf(ctx, standID, s.standFestivalID)
+	s.bump(ctx, festivalID, StreamProducts, err)
+}
+
+// StandPricesChanged counts a change of the prices of a stand
+func (s *Sequencer) StandPricesChanged(ctx context.Context, standID uuid.UUID) {
+	festivalID, err := s.festivalOf(ctx, standID, s.standFestivalID)
+	s.bump(ctx, festivalID, StreamPrices, err)
+}
+
+// PricesChanged counts a change of the prices of a festival, e.g. a price list
+// starting
+func (s *Sequencer) PricesChanged(ctx context.Context, festivalID uuid.UUID) {
+	s.bump(ctx, &festivalID, StreamPrices, nil)
+}
+
+// Sequences returns the change counters of a festival, zero for the streams that
+// never changed
+func (s *Sequencer) Sequences(ctx context.Context, festivalID uuid.UUID) (*Sequences, error) {
+	counters, err := s.store.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return &Sequences{
+		FestivalID: festivalID,
+		Wallets:    counters[StreamWallets],
+		Prices:     counters[StreamPrices],
+		Products:   counters[StreamProducts],
+		At:         s.now().UTC(),
+	}, nil
+}
+
+func (s *Sequencer) bump(ctx context.Context, festivalID *uuid.UUID, stream Stream, lookupErr error) {
+	if lookupErr != nil {
+		log.Error().Err(lookupErr).Str("stream", string(stream)).Msg("Failed to resolve festival of change")
+		return
+	}
+	if festivalID == nil {
+		return
+	}
+	if _, err := s.store.Increment(ctx, *festivalID, stream); err != nil {
+		log.Error().Err(err).Str("festival_id", festivalID.String()).Str("stream", string(stream)).Msg("Failed to bump POS sequence")
+	}
+}
+
+// festivalOf returns the festival of a wallet or stand, nil when it does not exist
+func (s *Sequencer) festivalOf(ctx context.Context, id uuid.UUID, lookup func(context.Context, uuid.UUID) (*uuid.UUID, error)) (*uuid.UUID, error) {
+	if cached, ok := s.festivals.Load(id); ok {
+		festivalID := cached.(uuid.UUID)
+		return &festivalID, nil
+	}
+
+	festivalID, err := lookup(ctx, id)
+	if err != nil || festivalID == nil {
+		return nil, err
+	}
+	s.festivals.Store(id, *festivalID)
+	return festivalID, nil
+}
+
+func (s *Sequencer) standFestivalID(ctx context.Context, standID uuid.UUID) (*uuid.UUID, error) {
+	stand, err := s.repo.GetStandInfo(ctx, standID)
+	if err != nil || stand == nil {
+		return nil, err
+	}
+	return &stand.FestivalID, nil
+}
@@ -0,0 +1,88 @@
+package posdevice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSequenceStore struct {
+	counters map[uuid.UUID]map[Stream]int64
+}
+
+func (s *fakeSequenceStore) Increment(ctx context.Context, festivalID uuid.UUID, stream Stream) (int64, error) {
+	if s.counters[festivalID] == nil {
+		s.counters[festivalID] = make(map[Stream]int64)
+	}
+	s.counters[festivalID][stream]++
+	return s.counters[festivalID][stream], nil
+}
+
+func (s *fakeSequenceStore) Get(ctx context.Context, festivalID uuid.UUID) (map[Stream]int64, error) {
+	return s.counters[festivalID], nil
+}
+
+func TestSequencer_CountsChangesPerFestival(t *testing.T) {
+	mockRepo := NewMockRepository()
+	store := &fakeSequenceStore{counters: make(map[uuid.UUID]map[Stream]int64)}
+	sequencer := NewSequencer(store, mockRepo)
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	sequencer.now = func() time.Time { return now }
+	festivalID, otherFestivalID := uuid.New(), uuid.New()
+	stand := testStand(festivalID, "Bar Nord")
+	walletID, unknownWalletID, unknownStandID := uuid.New(), uuid.New(), uuid.New()
+	ctx := context.Background()
+
+	// The festivals of the wallet and stand are only looked up once
+	mockRepo.On("GetWalletFestivalID", mock.Anything, walletID).Return(&festivalID, nil).Once()
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil).Once()
+	mockRepo.On("GetWalletFestivalID", mock.Anything, unknownWalletID).Return(nil, nil).Once()
+	mockRepo.On("GetStandInfo", mock.Anything, unknownStandID).Return(nil, nil).Once()
+
+	sequences, err := sequencer.Sequences(ctx, festivalID)
+	require.NoError(t, err)
+	assert.Equal(t, Sequences{FestivalID: festivalID, At: now}, *sequences)
+
+	sequencer.WalletChanged(ctx, walletID)
+	sequencer.WalletChanged(ctx, walletID)
+	sequencer.ProductsChanged(ctx, stand.ID)
+	sequencer.StandPricesChanged(ctx, stand.ID)
+	sequencer.PricesChanged(ctx, festivalID)
+	sequencer.PricesChanged(ctx, otherFestivalID)
+	// Changes of unknown wallets and stands are not counted
+	sequencer.WalletChanged(ctx, unknownWalletID)
+	sequencer.ProductsChanged(ctx, unknownStandID)
+
+	sequences, err = sequencer.Sequences(ctx, festivalID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), sequences.Wallets)
+	assert.Equal(t, int64(2), sequences.Prices)
+	assert.Equal(t, int64(1), sequences.Products)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_SequencesOfDevice(t *testing.T) {
+	mockRepo := NewMockRepository()
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	service, _ := newTestService(mockRepo, &now)
+	device := &Device{ID: uuid.New(), FestivalID: uuid.New()}
+	ctx := context.Background()
+
+	_, err := service.Sequences(ctx, device)
+	assert.ErrorIs(t, err, ErrSequencesDisabled)
+
+	store := &fakeSequenceStore{counters: make(map[uuid.UUID]map[Stream]int64)}
+	sequencer := NewSequencer(store, mockRepo)
+	service.SetSequencer(sequencer)
+	sequencer.PricesChanged(ctx, device.FestivalID)
+
+	sequences, err := service.Sequences(ctx, device)
+	require.NoError(t, err)
+	assert.Equal(t, device.FestivalID, sequences.FestivalID)
+	assert.Equal(t, int64(1), sequences.Prices)
+}
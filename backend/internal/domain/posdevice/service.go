@@ -0,0 +1,359 @@
+package posdevice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/rs/zerolog/log"
+)
+
+// QRGenerator renders QR code images; satisfied by qrcode.Generator
+type QRGenerator interface {
+	GenerateQRFromData(encodedPayload string) ([]byte, error)
+}
+
+// SigningSecrets holds the secrets device signing keys are derived from; satisfied
+// by security.Keyring
+type SigningSecrets interface {
+	Current() []byte
+}
+
+// Service pairs POS terminals to stands without staff typing passwords on them. An
+// organizer creates a short-lived pairing QR code on the dashboard, the terminal scans
+// it and exchanges the code for a device token bound to the stand, which works until
+// the device is unpaired.
+type Service struct {
+	repo    Repository
+	qr      QRGenerator
+	signing SigningSecrets
+	seq     *Sequencer
+	now     func() time.Time
+}
+
+// NewService creates a new POS device service
+func NewService(repo Repository, qr QRGenerator) *Service {
+	return &Service{
+		repo: repo,
+		qr:   qr,
+		now:  time.Now,
+	}
+}
+
+// SetSigningSecrets enables request signing, deriving the keys of the devices from
+// the secrets
+func (s *Service) SetSigningSecrets(secrets SigningSecrets) {
+	s.signing = secrets
+}
+
+// SetSequencer enables the change sequences the devices check their cache against
+func (s *Service) SetSequencer(sequencer *Sequencer) {
+	s.seq = sequencer
+}
+
+// CreatePairing creates a single-use pairing code for a stand and returns it with its
+// QR code, which are only shown once
+func (s *Service) CreatePairing(ctx context.Context, festivalID uuid.UUID, createdBy *uuid.UUID, req CreatePairingRequest) (*PairingWithCode, error) {
+	ttl := DefaultPairingTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+		if ttl < MinPairingTTL || ttl > MaxPairingTTL {
+			return nil, ErrInvalidTTL
+		}
+	}
+
+	stand, err := s.repo.GetStandInfo(ctx, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil || stand.FestivalID != festivalID {
+		return nil, ErrStandNotFound
+	}
+
+	code, err := generateSecret(PairingCodePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	pairing := &Pairing{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    req.StandID,
+		DeviceName: strings.TrimSpace(req.DeviceName),
+		CodeHash:   hashSecret(code),
+		CreatedBy:  createdBy,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+	}
+	if err := s.repo.CreatePairing(ctx, pairing); err != nil {
+		return nil, err
+	}
+
+	payload := PairingURI + "?" + url.Values{"code": {code}}.Encode()
+	png, err := s.qr.GenerateQRFromData(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render pairing QR code: %w", err)
+	}
+
+	return &PairingWithCode{
+		Pairing:   *pairing,
+		Code:      code,
+		QRPayload: payload,
+		QRCode:    "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// GetPairing returns a pairing, which the dashboard polls to know when the code was
+// scanned
+func (s *Service) GetPairing(ctx context.Context, festivalID, id uuid.UUID) (*Pairing, error) {
+	pairing, err := s.repo.GetPairing(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if pairing == nil {
+		return nil, ErrPairingNotFound
+	}
+	return pairing, nil
+}
+
+// ListPairings lists the pairing codes of a festival that can still be scanned
+func (s *Service) ListPairings(ctx context.Context, festivalID uuid.UUID) ([]Pairing, error) {
+	return s.repo.ListOpenPairings(ctx, festivalID, s.now())
+}
+
+// CancelPairing deletes a pairing code before it is scanned, e.g. when its QR code
+// was shown on a screen it should not have been
+func (s *Service) CancelPairing(ctx context.Context, festivalID, id uuid.UUID) error {
+	pairing, err := s.GetPairing(ctx, festivalID, id)
+	if err != nil {
+		return err
+	}
+	if pairing.UsedAt != nil {
+		return ErrPairingUsed
+	}
+	return s.repo.DeletePairing(ctx, id)
+}
+
+// Exchange pairs the POS terminal that scanned a pairing code to its stand and returns
+// the device token, which is only shown once. Each code pairs one device.
+func (s *Service) Exchange(ctx context.Context, req ExchangeRequest) (*PairedDevice, error) {
+	code := strings.TrimSpace(req.Code)
+	if !strings.HasPrefix(code, PairingCodePrefix) {
+		return nil, ErrInvalidPairingCode
+	}
+
+	now := s.now()
+	deviceID := uuid.New()
+	pairing, err := s.repo.ClaimPairing(ctx, hashSecret(code), deviceID, now)
+	if err != nil {
+		return nil, err
+	}
+	if pairing == nil {
+		return nil, ErrInvalidPairingCode
+	}
+
+	stand, err := s.repo.GetStandInfo(ctx, pairing.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil {
+		return nil, ErrStandNotFound
+	}
+
+	token, err := generateSecret(DeviceTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	name := pairing.DeviceName
+	if name == "" {
+		name = strings.TrimSpace(req.DeviceName)
+	}
+	if name == "" {
+		name = stand.Name + " POS"
+	}
+
+	device := &Device{
+		ID:          deviceID,
+		FestivalID:  pairing.FestivalID,
+		StandID:     pairing.StandID,
+		Name:        name,
+		Platform:    req.Platform,
+		AppVersion:  req.AppVersion,
+		TokenHash:   hashSecret(token),
+		TokenPrefix: token[:len(DeviceTokenPrefix)+6],
+		PairedBy:    pairing.CreatedBy,
+		PairedAt:    now,
+		LastSeenAt:  &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateDevice(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return &PairedDevice{
+		Session:     Session{Device: *device, Stand: *stand},
+		DeviceToken: token,
+	}, nil
+}
+
+// ListDevices lists the POS devices of a festival
+func (s *Service) ListDevices(ctx context.Context, festivalID uuid.UUID, query ListDevicesQuery) ([]DeviceResponse, error) {
+	devices, err := s.repo.ListDevices(ctx, festivalID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]DeviceResponse, len(devices))
+	for i := range devices {
+		responses[i] = s.toResponse(&devices[i])
+	}
+	return responses, nil
+}
+
+// GetDevice returns a POS device of a festival
+func (s *Service) GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*DeviceResponse, error) {
+	device, err := s.getDevice(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	response := s.toResponse(device)
+	return &response, nil
+}
+
+// Unpair revokes the token of a device at once, e.g. when a terminal is lost or moved
+// to another stand. The device is kept for the history of its sales; unpairing it
+// again changes nothing.
+func (s *Service) Unpair(ctx context.Context, festivalID, id uuid.UUID, unpairedBy *uuid.UUID) (*DeviceResponse, error) {
+	device, err := s.getDevice(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.UnpairedAt == nil {
+		if err := s.unpair(ctx, device, unpairedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	response := s.toResponse(device)
+	return &response, nil
+}
+
+// UnpairSelf unpairs the device making the request, when it is reset or handed back
+func (s *Service) UnpairSelf(ctx context.Context, device *Device) error {
+	return s.unpair(ctx, device, nil)
+}
+
+// AuthenticateDevice resolves the device of a device token. The tokens of unpaired
+// devices fail with ErrDeviceUnpaired, so the terminal knows to return to pairing.
+func (s *Service) AuthenticateDevice(ctx context.Context, token string) (*Device, error) {
+	if !strings.HasPrefix(token, DeviceTokenPrefix) {
+		return nil, ErrInvalidDeviceToken
+	}
+
+	device, err := s.repo.GetDeviceByTokenHash(ctx, hashSecret(token))
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, ErrInvalidDeviceToken
+	}
+	if device.UnpairedAt != nil {
+		return nil, ErrDeviceUnpaired
+	}
+
+	now := s.now()
+	if device.LastSeenAt == nil || now.Sub(*device.LastSeenAt) >= touchInterval {
+		if err := s.repo.TouchDevice(ctx, device.ID, now); err != nil {
+			log.Warn().Err(err).Str("device_id", device.ID.String()).Msg("Failed to record POS device activity")
+		} else {
+			device.LastSeenAt = &now
+		}
+	}
+	return device, nil
+}
+
+// Session returns the device making the request with its stand
+func (s *Service) Session(ctx context.Context, device *Device) (*Session, error) {
+	stand, err := s.repo.GetStandInfo(ctx, device.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil {
+		return nil, ErrStandNotFound
+	}
+	return &Session{Device: *device, Stand: *stand}, nil
+}
+
+// SigningKey returns the key the device signs its requests with, derived from the
+// current secret so that it is never stored
+func (s *Service) SigningKey(device *Device) (*SigningKey, error) {
+	if s.signing == nil {
+		return nil, ErrSigningDisabled
+	}
+	key := security.RequestSigningKey(s.signing.Current(), device.ID.String())
+	return &SigningKey{
+		Key:       base64.StdEncoding.EncodeToString(key),
+		Algorithm: security.SignatureAlgorithm,
+		Headers:   []string{security.SignatureHeader, security.SignatureTimestampHeader, security.SignatureNonceHeader},
+	}, nil
+}
+
+// Sequences returns the change counters of the festival of the device making the
+// request
+func (s *Service) Sequences(ctx context.Context, device *Device) (*Sequences, error) {
+	if s.seq == nil {
+		return nil, ErrSequencesDisabled
+	}
+	return s.seq.Sequences(ctx, device.FestivalID)
+}
+
+func (s *Service) unpair(ctx context.Context, device *Device, unpairedBy *uuid.UUID) error {
+	now := s.now()
+	device.UnpairedAt = &now
+	device.UnpairedBy = unpairedBy
+	device.UpdatedAt = now
+	return s.repo.UpdateDevice(ctx, device)
+}
+
+func (s *Service) getDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error) {
+	device, err := s.repo.GetDevice(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+func (s *Service) toResponse(device *Device) DeviceResponse {
+	return DeviceResponse{
+		Device: *device,
+		Online: device.UnpairedAt == nil && device.LastSeenAt != nil && s.now().Sub(*device.LastSeenAt) < OnlineWindow,
+	}
+}
+
+func generateSecret(prefix string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate POS device secret: %w", err)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
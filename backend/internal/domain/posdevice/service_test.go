@@ -0,0 +1,157 @@
+package posdevice
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testStand(festivalID uuid.UUID, name string) *StandInfo {
+	return &StandInfo{ID: uuid.New(), FestivalID: festivalID, Name: name, FestivalName: "Summer Fest"}
+}
+
+// expectPairings records the pairings created by the service
+func expectPairings(mockRepo *MockRepository) *[]*Pairing {
+	pairings := &[]*Pairing{}
+	mockRepo.On("CreatePairing", mock.Anything, mock.AnythingOfType("*posdevice.Pairing")).
+		Run(func(args mock.Arguments) { *pairings = append(*pairings, args.Get(1).(*Pairing)) }).
+		Return(nil)
+	return pairings
+}
+
+// expectClaim lets a pairing be claimed once at now, for the device the service pairs
+func expectClaim(mockRepo *MockRepository, pairing *Pairing, now time.Time) *Pairing {
+	claimed := *pairing
+	mockRepo.On("ClaimPairing", mock.Anything, pairing.CodeHash, mock.AnythingOfType("uuid.UUID"), now).
+		Run(func(args mock.Arguments) {
+			deviceID := args.Get(2).(uuid.UUID)
+			claimed.UsedAt = &now
+			claimed.DeviceID = &deviceID
+		}).
+		Return(&claimed, nil).Once()
+	return &claimed
+}
+
+type fakeQRGenerator struct {
+	payloads []string
+}
+
+func (g *fakeQRGenerator) GenerateQRFromData(encodedPayload string) ([]byte, error) {
+	g.payloads = append(g.payloads, encodedPayload)
+	return []byte("png"), nil
+}
+
+func newTestService(repo Repository, now *time.Time) (*Service, *fakeQRGenerator) {
+	qr := &fakeQRGenerator{}
+	service := NewService(repo, qr)
+	service.now = func() time.Time { return *now }
+	return service, qr
+}
+
+func TestService_PairingCodeIsSingleUseAndExpires(t *testing.T) {
+	mockRepo := NewMockRepository()
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	service, qr := newTestService(mockRepo, &now)
+	festivalID := uuid.New()
+	stand := testStand(festivalID, "Bar Nord")
+	ctx := context.Background()
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	mockRepo.On("CreateDevice", mock.Anything, mock.AnythingOfType("*posdevice.Device")).Return(nil).Once()
+	pairings := expectPairings(mockRepo)
+
+	_, err := service.CreatePairing(ctx, festivalID, nil, CreatePairingRequest{StandID: stand.ID, TTLSeconds: 10})
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+	_, err = service.CreatePairing(ctx, uuid.New(), nil, CreatePairingRequest{StandID: stand.ID})
+	assert.ErrorIs(t, err, ErrStandNotFound)
+
+	pairing, err := service.CreatePairing(ctx, festivalID, nil, CreatePairingRequest{StandID: stand.ID})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(pairing.Code, PairingCodePrefix))
+	assert.Equal(t, now.Add(DefaultPairingTTL), pairing.ExpiresAt)
+	require.Len(t, qr.payloads, 1)
+	assert.Equal(t, PairingURI+"?code="+pairing.Code, qr.payloads[0])
+	require.Len(t, *pairings, 1)
+	assert.Equal(t, hashSecret(pairing.Code), (*pairings)[0].CodeHash)
+
+	claimed := expectClaim(mockRepo, (*pairings)[0], now)
+	paired, err := service.Exchange(ctx, ExchangeRequest{Code: pairing.Code, Platform: "android"})
+	require.NoError(t, err)
+	assert.Equal(t, stand.ID, paired.Device.StandID)
+	assert.Equal(t, "Bar Nord POS", paired.Device.Name)
+	assert.True(t, strings.HasPrefix(paired.DeviceToken, DeviceTokenPrefix))
+	assert.Equal(t, &paired.Device.ID, claimed.DeviceID)
+
+	// A code pairs a single device
+	mockRepo.On("ClaimPairing", mock.Anything, claimed.CodeHash, mock.Anything, now).Return(nil, nil).Once()
+	_, err = service.Exchange(ctx, ExchangeRequest{Code: pairing.Code})
+	assert.ErrorIs(t, err, ErrInvalidPairingCode)
+	mockRepo.On("GetPairing", mock.Anything, festivalID, pairing.ID).Return(claimed, nil).Once()
+	assert.ErrorIs(t, service.CancelPairing(ctx, festivalID, pairing.ID), ErrPairingUsed)
+
+	expired, err := service.CreatePairing(ctx, festivalID, nil, CreatePairingRequest{StandID: stand.ID, TTLSeconds: 60})
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	mockRepo.On("ClaimPairing", mock.Anything, hashSecret(expired.Code), mock.Anything, now).Return(nil, nil).Once()
+	_, err = service.Exchange(ctx, ExchangeRequest{Code: expired.Code})
+	assert.ErrorIs(t, err, ErrInvalidPairingCode)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "DeletePairing", mock.Anything, mock.Anything)
+}
+
+func TestService_UnpairRevokesDeviceToken(t *testing.T) {
+	mockRepo := NewMockRepository()
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	service, _ := newTestService(mockRepo, &now)
+	festivalID := uuid.New()
+	stand := testStand(festivalID, "Bar Nord")
+	organizerID := uuid.New()
+	ctx := context.Background()
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	pairings := expectPairings(mockRepo)
+
+	// The device is served back as stored, so unpairing it revokes its token
+	stored := &Device{}
+	mockRepo.On("CreateDevice", mock.Anything, mock.AnythingOfType("*posdevice.Device")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Device) }).
+		Return(nil).Once()
+
+	pairing, err := service.CreatePairing(ctx, festivalID, &organizerID, CreatePairingRequest{StandID: stand.ID, DeviceName: "Till 1"})
+	require.NoError(t, err)
+	expectClaim(mockRepo, (*pairings)[0], now)
+	paired, err := service.Exchange(ctx, ExchangeRequest{Code: pairing.Code, DeviceName: "Galaxy Tab"})
+	require.NoError(t, err)
+	assert.Equal(t, "Till 1", paired.Device.Name)
+	assert.Equal(t, &organizerID, paired.Device.PairedBy)
+
+	mockRepo.On("GetDeviceByTokenHash", mock.Anything, hashSecret(paired.DeviceToken)).Return(stored, nil)
+	device, err := service.AuthenticateDevice(ctx, paired.DeviceToken)
+	require.NoError(t, err)
+	assert.Equal(t, paired.Device.ID, device.ID)
+
+	mockRepo.On("GetDeviceByTokenHash", mock.Anything, hashSecret(DeviceTokenPrefix+"unknown")).Return(nil, nil).Once()
+	_, err = service.AuthenticateDevice(ctx, DeviceTokenPrefix+"unknown")
+	assert.ErrorIs(t, err, ErrInvalidDeviceToken)
+
+	now = now.Add(10 * time.Minute)
+	mockRepo.On("GetDevice", mock.Anything, festivalID, device.ID).Return(stored, nil).Once()
+	mockRepo.On("UpdateDevice", mock.Anything, mock.MatchedBy(func(d *Device) bool {
+		return d.ID == device.ID && d.UnpairedBy != nil && *d.UnpairedBy == organizerID
+	})).Return(nil).Once()
+	unpaired, err := service.Unpair(ctx, festivalID, device.ID, &organizerID)
+	require.NoError(t, err)
+	assert.False(t, unpaired.Online)
+	assert.Equal(t, &now, unpaired.UnpairedAt)
+
+	_, err = service.AuthenticateDevice(ctx, paired.DeviceToken)
+	assert.ErrorIs(t, err, ErrDeviceUnpaired)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "TouchDevice", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,154 @@
+package posdevice
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+// SignedRequestConfig holds configuration for the verification of signed device requests
+type SignedRequestConfig struct {
+	// Keyring the signing keys of the clients are derived from
+	Keyring *security.Keyring
+	// Redis client recording the nonces already used
+	RedisClient *redis.Client
+
+	// Context key holding the ID of the client, set by its authentication middleware
+	ClientKey string
+
+	// How far the timestamp of a request may be from now
+	MaxSkew time.Duration
+
+	// Reject unsigned requests; when false only the requests carrying a signature
+	// are verified, so devices can adopt signing one at a time
+	Required bool
+
+	// Security auditor recording the rejected requests
+	Auditor *security.SecurityAuditor
+}
+
+// DefaultSignedRequestConfig returns default signed request configuration
+func DefaultSignedRequestConfig(keyring *security.Keyring, redisClient *redis.Client, clientKey string) SignedRequestConfig {
+	return SignedRequestConfig{
+		Keyring:     keyring,
+		RedisClient: redisClient,
+		ClientKey:   clientKey,
+		MaxSkew:     5 * time.Minute,
+	}
+}
+
+// SignedRequests verifies the HMAC signature of device requests and rejects the stale
+// or replayed ones, so a request captured on the venue network cannot be sent again
+// to debit a wallet twice. It must run after the middleware authenticating the
+// device, Authenticate or IdentifyDevice, which sets its ID under ClientKey.
+func SignedRequests(cfg SignedRequestConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := c.GetString(cfg.ClientKey)
+		signature := c.GetHeader(security.SignatureHeader)
+		if clientID == "" || signature == "" {
+			if cfg.Required {
+				rejectSignedRequest(c, cfg, clientID, security.EventAuthTokenInvalid, "missing")
+				abortSignedRequest(c, http.StatusUnauthorized, "SIGNATURE_REQUIRED", "Requests must be signed")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		timestamp, err := strconv.ParseInt(c.GetHeader(security.SignatureTimestampHeader), 10, 64)
+		nonce := c.GetHeader(security.SignatureNonceHeader)
+		if err != nil || len(nonce) < 16 || len(nonce) > 128 {
+			rejectSignedRequest(c, cfg, clientID, security.EventAuthTokenInvalid, "malformed")
+			abortSignedRequest(c, http.StatusUnauthorized, "SIGNATURE_INVALID", "Invalid request signature")
+			return
+		}
+
+		skew := time.Since(time.Unix(timestamp, 0))
+		if skew > cfg.MaxSkew || skew < -cfg.MaxSkew {
+			rejectSignedRequest(c, cfg, clientID, security.EventAttackReplay, "stale")
+			abortSignedRequest(c, http.StatusUnauthorized, "SIGNATURE_EXPIRED", "Request timestamp is too far from the server time")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortSignedRequest(c, http.StatusBadRequest, "INVALID_BODY", "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		valid := cfg.Keyring.Verify(signature, func(secret []byte) string {
+			key := security.RequestSigningKey(secret, clientID)
+			return security.SignRequest(key, c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+		})
+		if !valid {
+			rejectSignedRequest(c, cfg, clientID, security.EventAuthTokenInvalid, "mismatch")
+			abortSignedRequest(c, http.StatusUnauthorized, "SIGNATURE_INVALID", "Invalid request signature")
+			return
+		}
+
+		// Nonces are only checked once the signature is valid, so that nobody else can
+		// use up the nonces of a device. They are kept while their timestamp is accepted.
+		key := "signature:nonce:" + clientID + ":" + nonce
+		fresh, err := cfg.RedisClient.SetNX(c.Request.Context(), key, 1, 2*cfg.MaxSkew).Result()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to record request nonce")
+			abortSignedRequest(c, http.StatusServiceUnavailable, "SIGNATURE_UNAVAILABLE", "Unable to verify the request, try again")
+			return
+		}
+		if !fresh {
+			rejectSignedRequest(c, cfg, clientID, security.EventAttackReplay, "replayed")
+			abortSignedRequest(c, http.StatusConflict, "REQUEST_REPLAYED", "Request was already processed")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rejectSignedRequest logs and audits a request rejected for its signature
+func rejectSignedRequest(c *gin.Context, cfg SignedRequestConfig, clientID string, eventType security.SecurityEventType, reason string) {
+	log.Warn().
+		Str("reason", reason).
+		Str("client_id", clientID).
+		Str("path", c.Request.URL.Path).
+		Str("method", c.Request.Method).
+		Str("ip", privacy.LogIP(c.ClientIP())).
+		Msg("Signed request rejected")
+
+	if cfg.Auditor == nil {
+		return
+	}
+
+	cfg.Auditor.LogEvent(c.Request.Context(), &security.SecurityEvent{
+		Type:      eventType,
+		Severity:  security.SeverityWarning,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		Resource:  c.Request.URL.Path,
+		Action:    c.Request.Method,
+		Result:    "blocked",
+		Details: map[string]interface{}{
+			"reason":    reason,
+			"client_id": clientID,
+			"nonce":     c.GetHeader(security.SignatureNonceHeader),
+		},
+	})
+}
+
+// abortSignedRequest answers a request rejected for its signature
+func abortSignedRequest(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, response.ErrorResponse{
+		Error: response.ErrorDetail{Code: code, Message: message},
+	})
+}
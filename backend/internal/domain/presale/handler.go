@@ -0,0 +1,401 @@
+package presale
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the campaigns, the pre-sales and their report, which should
+// be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	campaigns := r.Group("/presale-campaigns")
+	{
+		campaigns.GET("", h.ListCampaigns)
+		campaigns.POST("", h.CreateCampaign)
+		campaigns.PATCH("/:campaignId", h.UpdateCampaign)
+	}
+	r.GET("/presales", h.ListPreSales)
+	r.GET("/presales/:preSaleId", h.GetPreSale)
+	r.GET("/presale-report", h.GetReport)
+}
+
+// RegisterAttendeeRoutes registers the pre-sale top-ups of the attendee app, open to
+// every authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	r.GET("/presale-offer", h.Quote)
+	r.POST("/presales", h.Checkout)
+	r.GET("/me/presales", h.ListMyPreSales)
+}
+
+// CreateCampaign adds a pre-sale campaign
+// @Summary Create pre-sale campaign
+// @Description Offer a bonus on the wallet top-ups bought before the gates open, e.g. 500 for a top-up of at least 5000. With repeat, the bonus is given for every minAmount topped up. Amounts are in cents.
+// @Tags presales
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateCampaignRequest true "Campaign"
+// @Success 201 {object} response.Response{data=Campaign} "Created campaign"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presale-campaigns [post]
+func (h *Handler) CreateCampaign(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	campaign, err := h.service.CreateCampaign(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, campaign)
+}
+
+// UpdateCampaign changes a pre-sale campaign
+// @Summary Update pre-sale campaign
+// @Description Change the bonus or the period of a campaign, or stop it. Pre-sales already started keep the bonus they were sold with.
+// @Tags presales
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param campaignId path string true "Campaign ID" format(uuid)
+// @Param request body UpdateCampaignRequest true "Changes"
+// @Success 200 {object} response.Response{data=Campaign} "Updated campaign"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Campaign not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presale-campaigns/{campaignId} [patch]
+func (h *Handler) UpdateCampaign(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	campaignID, err := uuid.Parse(c.Param("campaignId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid campaign ID", nil)
+		return
+	}
+
+	var req UpdateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	campaign, err := h.service.UpdateCampaign(c.Request.Context(), festivalID, campaignID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, campaign)
+}
+
+// ListCampaigns lists the pre-sale campaigns
+// @Summary List pre-sale campaigns
+// @Description List the pre-sale campaigns of the festival, running or not
+// @Tags presales
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Campaign} "Campaigns"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presale-campaigns [get]
+func (h *Handler) ListCampaigns(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	campaigns, err := h.service.ListCampaigns(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, campaigns)
+}
+
+// Quote returns the bonus of a pre-sale top-up
+// @Summary Get pre-sale offer
+// @Description Get the bonus a pre-sale top-up of an amount gets from the best running campaign, and when the wallet is credited
+// @Tags presales
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param amount query int true "Top-up in cents, at least 100"
+// @Success 200 {object} response.Response{data=Offer} "Offer"
+// @Failure 400 {object} response.ErrorResponse "Invalid amount"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Gates already open"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presale-offer [get]
+func (h *Handler) Quote(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	amount, err := strconv.ParseInt(c.Query("amount"), 10, 64)
+	if err != nil || amount < 100 {
+		response.BadRequest(c, "INVALID_AMOUNT", "Amount must be at least 100 cents", nil)
+		return
+	}
+
+	offer, err := h.service.Quote(c.Request.Context(), festivalID, amount)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, offer)
+}
+
+// Checkout starts a pre-sale top-up
+// @Summary Buy pre-sale top-up
+// @Description Start a wallet top-up by the calling attendee before the gates open. The response carries the client secret of the card payment; the top-up and its bonus are credited to the attendee's wallet of the festival when the gates open.
+// @Tags presales
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CheckoutRequest true "Top-up"
+// @Success 201 {object} response.Response{data=Checkout} "Pre-sale waiting for its payment"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or wallet not active"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Gates already open"
+// @Failure 503 {object} response.ErrorResponse "Card payments not configured"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presales [post]
+func (h *Handler) Checkout(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	checkout, err := h.service.Checkout(c.Request.Context(), festivalID, userID, c.GetString("email"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, checkout)
+}
+
+// ListMyPreSales lists the pre-sale top-ups of the calling attendee
+// @Summary List my pre-sale top-ups
+// @Description List the pre-sale top-ups the calling attendee bought for the festival, latest first
+// @Tags presales
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]PreSale} "Pre-sales"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/presales [get]
+func (h *Handler) ListMyPreSales(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	preSales, err := h.service.ListPreSales(c.Request.Context(), festivalID, PreSaleFilter{UserID: &userID, Limit: 100})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, preSales)
+}
+
+// ListPreSales lists the pre-sale top-ups
+// @Summary List pre-sale top-ups
+// @Description List the pre-sale top-ups of the festival, latest first
+// @Tags presales
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param campaignId query string false "Campaign ID" format(uuid)
+// @Param status query string false "Status" Enums(PENDING, PAID, ACTIVATED)
+// @Param limit query int false "Maximum pre-sales, 1 to 1000" default(100)
+// @Success 200 {object} response.Response{data=[]PreSale} "Pre-sales"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presales [get]
+func (h *Handler) ListPreSales(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	filter := PreSaleFilter{Limit: 100}
+	if raw := c.Query("campaignId"); raw != "" {
+		campaignID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid campaign ID", nil)
+			return
+		}
+		filter.CampaignID = &campaignID
+	}
+	if raw := c.Query("status"); raw != "" {
+		status := PreSaleStatus(raw)
+		switch status {
+		case PreSaleStatusPending, PreSaleStatusPaid, PreSaleStatusActivated:
+			filter.Status = &status
+		default:
+			response.BadRequest(c, "INVALID_STATUS", "Status must be PENDING, PAID or ACTIVATED", nil)
+			return
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 1000 {
+			response.BadRequest(c, "INVALID_LIMIT", "Limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	preSales, err := h.service.ListPreSales(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, preSales)
+}
+
+// GetPreSale returns a pre-sale top-up
+// @Summary Get pre-sale top-up
+// @Description Get a pre-sale top-up with the wallet transactions of its top-up and bonus once credited
+// @Tags presales
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param preSaleId path string true "Pre-sale ID" format(uuid)
+// @Success 200 {object} response.Response{data=PreSale} "Pre-sale"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Pre-sale not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presales/{preSaleId} [get]
+func (h *Handler) GetPreSale(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	preSaleID, err := uuid.Parse(c.Param("preSaleId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid pre-sale ID", nil)
+		return
+	}
+
+	preSale, err := h.service.GetPreSale(c.Request.Context(), festivalID, preSaleID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, preSale)
+}
+
+// GetReport returns the pre-sale report
+// @Summary Get pre-sale report
+// @Description Split the money received before the gates opened, pre-sale top-ups and ticket sales, from the top-ups and sales on site. Bonuses are reported apart, as they are not cash.
+// @Tags presales
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Report} "Report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/presale-report [get]
+func (h *Handler) GetReport(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetReport(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+// userParam returns the calling attendee
+func userParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrCampaignNotFound):
+		response.NotFound(c, "Pre-sale campaign not found")
+	case errors.Is(err, ErrPreSaleNotFound):
+		response.NotFound(c, "Pre-sale top-up not found")
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrCampaignPeriod):
+		response.BadRequest(c, "INVALID_PERIOD", err.Error(), nil)
+	case errors.Is(err, ErrWalletNotActive):
+		response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+	case errors.Is(err, ErrPreSaleClosed):
+		response.Conflict(c, "PRESALE_CLOSED", err.Error())
+	case errors.Is(err, ErrPaymentsUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
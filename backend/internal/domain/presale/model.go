@@ -0,0 +1,187 @@
+package presale
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pre-sale errors
+var (
+	ErrCampaignNotFound    = errors.New("pre-sale campaign not found")
+	ErrPreSaleNotFound     = errors.New("pre-sale top-up not found")
+	ErrFestivalNotFound    = errors.New("festival not found")
+	ErrCampaignPeriod      = errors.New("campaign must end after it starts")
+	ErrPreSaleClosed       = errors.New("pre-sales are closed, the festival gates are open: top up the wallet directly")
+	ErrWalletNotActive     = errors.New("wallet is not active")
+	ErrPaymentsUnavailable = errors.New("card payments are not configured")
+)
+
+// Campaign is a bonus offered on the wallet top-ups bought before the festival gates
+// open, e.g. 5 EUR free for a 50 EUR top-up
+type Campaign struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Name        string     `json:"name" gorm:"not null"`
+	MinAmount   int64      `json:"minAmount" gorm:"not null"`   // Top-up earning the bonus, in cents
+	BonusAmount int64      `json:"bonusAmount" gorm:"not null"` // Credited on top of the top-up, in cents
+	Repeat      bool       `json:"repeat" gorm:"not null"`      // Bonus earned for every MinAmount topped up, e.g. 10 EUR for 100 EUR
+	StartsAt    time.Time  `json:"startsAt" gorm:"not null"`
+	EndsAt      *time.Time `json:"endsAt,omitempty"` // Nil to run until the gates open
+	Active      bool       `json:"active" gorm:"not null"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Campaign) TableName() string {
+	return "presale_campaigns"
+}
+
+// Bonus returns the bonus the campaign gives on a top-up of amount, 0 when too small
+func (c *Campaign) Bonus(amount int64) int64 {
+	if c.MinAmount <= 0 || amount < c.MinAmount {
+		return 0
+	}
+	if c.Repeat {
+		return amount / c.MinAmount * c.BonusAmount
+	}
+	return c.BonusAmount
+}
+
+// Running reports whether the campaign gives its bonus at now
+func (c *Campaign) Running(now time.Time) bool {
+	return c.Active && !now.Before(c.StartsAt) && (c.EndsAt == nil || now.Before(*c.EndsAt))
+}
+
+// Festival is a festival as far as pre-sales are concerned
+type Festival struct {
+	ID          uuid.UUID
+	StartDate   time.Time
+	Timezone    string
+	DayStartsAt string
+}
+
+// PreSaleStatus is the state of a pre-sale top-up
+type PreSaleStatus string
+
+const (
+	PreSaleStatusPending   PreSaleStatus = "PENDING"   // Waiting for the payment
+	PreSaleStatusPaid      PreSaleStatus = "PAID"      // Paid, held until the gates open
+	PreSaleStatusActivated PreSaleStatus = "ACTIVATED" // Top-up and bonus credited to the wallet
+)
+
+// PreSale is a wallet top-up paid by card before the festival gates open. The money is
+// held, not credited, until the gates open, when the top-up and its bonus are added to
+// the wallet together.
+type PreSale struct {
+	ID                 uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID         uuid.UUID     `json:"festivalId" gorm:"type:uuid;not null;index"`
+	UserID             uuid.UUID     `json:"userId" gorm:"type:uuid;not null"`
+	WalletID           uuid.UUID     `json:"walletId" gorm:"type:uuid;not null"`
+	CampaignID         *uuid.UUID    `json:"campaignId,omitempty" gorm:"type:uuid"`
+	StripeIntentID     string        `json:"stripeIntentId" gorm:"not null;uniqueIndex"`
+	Amount             int64         `json:"amount" gorm:"not null"`      // Paid by card, in cents
+	BonusAmount        int64         `json:"bonusAmount" gorm:"not null"` // Credited on top, in cents
+	Status             PreSaleStatus `json:"status" gorm:"not null"`
+	TopUpTransactionID *uuid.UUID    `json:"topUpTransactionId,omitempty" gorm:"type:uuid"`
+	BonusTransactionID *uuid.UUID    `json:"bonusTransactionId,omitempty" gorm:"type:uuid"`
+	PaidAt             *time.Time    `json:"paidAt,omitempty"`
+	ActivatedAt        *time.Time    `json:"activatedAt,omitempty"`
+	CreatedAt          time.Time     `json:"createdAt"`
+	UpdatedAt          time.Time     `json:"updatedAt"`
+}
+
+func (PreSale) TableName() string {
+	return "wallet_presales"
+}
+
+// PreSaleFilter narrows the listed pre-sales
+type PreSaleFilter struct {
+	CampaignID *uuid.UUID
+	UserID     *uuid.UUID
+	Status     *PreSaleStatus
+	Limit      int
+}
+
+// Offer is what a top-up of an amount is credited before the gates open
+type Offer struct {
+	Amount      int64      `json:"amount"`
+	BonusAmount int64      `json:"bonusAmount"`
+	CampaignID  *uuid.UUID `json:"campaignId,omitempty"`
+	Campaign    string     `json:"campaign,omitempty"`
+	ActivatesAt time.Time  `json:"activatesAt"` // When the gates open and the wallet is credited
+}
+
+// Checkout is a pre-sale top-up waiting for its payment, with the client secret the app
+// confirms the payment with
+type Checkout struct {
+	PreSale      PreSale   `json:"preSale"`
+	ClientSecret string    `json:"clientSecret"`
+	ActivatesAt  time.Time `json:"activatesAt"`
+}
+
+// Report splits the money a festival received before its gates opened from the money
+// received on site, in cents
+type Report struct {
+	FestivalID  uuid.UUID        `json:"festivalId"`
+	GatesOpenAt time.Time        `json:"gatesOpenAt"`
+	PreSale     PreSaleTotals    `json:"preSale"`
+	OnSite      OnSiteTotals     `json:"onSite"`
+	Campaigns   []CampaignTotals `json:"campaigns"`
+}
+
+// PreSaleTotals is the money received before the gates opened
+type PreSaleTotals struct {
+	TopUps         int64 `json:"topUps"`         // Pre-sale top-ups paid
+	CashReceived   int64 `json:"cashReceived"`   // Paid for the pre-sale top-ups
+	BonusGranted   int64 `json:"bonusGranted"`   // Bonus of the paid pre-sale top-ups, not cash
+	Held           int64 `json:"held"`           // Paid and not credited yet
+	Activated      int64 `json:"activated"`      // Paid and credited
+	OtherTopUps    int64 `json:"otherTopUps"`    // Top-ups credited before the gates opened, outside pre-sales
+	TicketSales    int64 `json:"ticketSales"`    // Card payments for tickets, entry bundles included
+	CashBeforeGate int64 `json:"cashBeforeGate"` // Everything received before the gates opened
+}
+
+// OnSiteTotals is the money received since the gates opened
+type OnSiteTotals struct {
+	CardTopUps int64 `json:"cardTopUps"`
+	CashTopUps int64 `json:"cashTopUps"`
+	TopUps     int64 `json:"topUps"` // Card and cash top-ups
+	Sales      int64 `json:"sales"`  // Spent at the stands, pre-sale balances included
+}
+
+// CampaignTotals is what a campaign brought in
+type CampaignTotals struct {
+	CampaignID   uuid.UUID `json:"campaignId"`
+	Name         string    `json:"name"`
+	TopUps       int64     `json:"topUps"`
+	CashReceived int64     `json:"cashReceived"`
+	BonusGranted int64     `json:"bonusGranted"`
+}
+
+// CreateCampaignRequest adds a pre-sale campaign
+type CreateCampaignRequest struct {
+	Name        string     `json:"name" binding:"required,max=100"`
+	MinAmount   int64      `json:"minAmount" binding:"required,min=100"` // At least 1 EUR, the smallest card payment
+	BonusAmount int64      `json:"bonusAmount" binding:"required,min=1"`
+	Repeat      bool       `json:"repeat"`
+	StartsAt    *time.Time `json:"startsAt,omitempty"` // Now when not given
+	EndsAt      *time.Time `json:"endsAt,omitempty"`
+}
+
+// UpdateCampaignRequest changes a campaign; the pre-sales already started keep their bonus
+type UpdateCampaignRequest struct {
+	Name        *string    `json:"name,omitempty" binding:"omitempty,max=100"`
+	MinAmount   *int64     `json:"minAmount,omitempty" binding:"omitempty,min=100"`
+	BonusAmount *int64     `json:"bonusAmount,omitempty" binding:"omitempty,min=1"`
+	Repeat      *bool      `json:"repeat,omitempty"`
+	StartsAt    *time.Time `json:"startsAt,omitempty"`
+	EndsAt      *time.Time `json:"endsAt,omitempty"`
+	Active      *bool      `json:"active,omitempty"`
+}
+
+// CheckoutRequest starts a pre-sale top-up by the signed-in attendee
+type CheckoutRequest struct {
+	Amount int64 `json:"amount" binding:"required,min=100"` // In cents, at least 1 EUR
+}
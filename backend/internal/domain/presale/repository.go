@@ -0,0 +1,381 @@
+package presale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateCampaign(ctx context.Context, campaign *Campaign) error
+	UpdateCampaign(ctx context.Context, campaign *Campaign) error
+	GetCampaign(ctx context.Context, festivalID, id uuid.UUID) (*Campaign, error)
+	ListCampaigns(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Campaign, error)
+	GetFestival(ctx context.Context, id uuid.UUID) (*Festival, error)
+
+	CreatePreSale(ctx context.Context, preSale *PreSale) error
+	GetPreSale(ctx context.Context, festivalID, id uuid.UUID) (*PreSale, error)
+	GetPreSaleByIntent(ctx context.Context, stripeIntentID string) (*PreSale, error)
+	ListPreSales(ctx context.Context, festivalID uuid.UUID, filter PreSaleFilter) ([]PreSale, error)
+	// MarkPaid moves a pending pre-sale to paid. A pre-sale no longer pending is left as it
+	// is; either way the pre-sale is reloaded.
+	MarkPaid(ctx context.Context, preSale *PreSale, paidAt time.Time) error
+	// ListHeldFestivals returns the festivals of the scope with paid pre-sales waiting for
+	// their gates to open
+	ListHeldFestivals(ctx context.Context, scope clock.Scope) ([]Festival, error)
+	// ListHeld returns the IDs of paid pre-sales of a festival, oldest first
+	ListHeld(ctx context.Context, festivalID uuid.UUID, limit int) ([]uuid.UUID, error)
+	// Activate credits the wallet of a paid pre-sale with its top-up and its bonus in one
+	// transaction, and marks it activated. A pre-sale no longer paid is left as it is. It
+	// returns ErrWalletNotActive when the wallet cannot be credited.
+	Activate(ctx context.Context, id uuid.UUID, now time.Time) (*PreSale, error)
+
+	GetReport(ctx context.Context, festivalID uuid.UUID, gatesOpenAt time.Time) (*Report, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateCampaign(ctx context.Context, campaign *Campaign) error {
+	if err := r.db.WithContext(ctx).Create(campaign).Error; err != nil {
+		return fmt.Errorf("failed to create pre-sale campaign: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateCampaign(ctx context.Context, campaign *Campaign) error {
+	if err := r.db.WithContext(ctx).Save(campaign).Error; err != nil {
+		return fmt.Errorf("failed to update pre-sale campaign: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetCampaign(ctx context.Context, festivalID, id uuid.UUID) (*Campaign, error) {
+	var campaign Campaign
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&campaign).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pre-sale campaign: %w", err)
+	}
+	return &campaign, nil
+}
+
+func (r *repository) ListCampaigns(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Campaign, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if activeOnly {
+		query = query.Where("active")
+	}
+	var campaigns []Campaign
+	if err := query.Order("starts_at, name").Find(&campaigns).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pre-sale campaigns: %w", err)
+	}
+	return campaigns, nil
+}
+
+func (r *repository) GetFestival(ctx context.Context, id uuid.UUID) (*Festival, error) {
+	var festival Festival
+	err := r.db.WithContext(ctx).
+		Raw("SELECT id, start_date, timezone, day_starts_at FROM festivals WHERE id = ?", id).
+		Scan(&festival).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if festival.ID == uuid.Nil {
+		return nil, nil
+	}
+	return &festival, nil
+}
+
+func (r *repository) CreatePreSale(ctx context.Context, preSale *PreSale) error {
+	if err := r.db.WithContext(ctx).Create(preSale).Error; err != nil {
+		return fmt.Errorf("failed to create pre-sale: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetPreSale(ctx context.Context, festivalID, id uuid.UUID) (*PreSale, error) {
+	var preSale PreSale
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&preSale).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pre-sale: %w", err)
+	}
+	return &preSale, nil
+}
+
+func (r *repository) GetPreSaleByIntent(ctx context.Context, stripeIntentID string) (*PreSale, error) {
+	var preSale PreSale
+	err := r.db.WithContext(ctx).Where("stripe_intent_id = ?", stripeIntentID).First(&preSale).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pre-sale: %w", err)
+	}
+	return &preSale, nil
+}
+
+func (r *repository) ListPreSales(ctx context.Context, festivalID uuid.UUID, filter PreSaleFilter) ([]PreSale, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if filter.CampaignID != nil {
+		query = query.Where("campaign_id = ?", *filter.CampaignID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	var preSales []PreSale
+	if err := query.Order("created_at DESC").Limit(filter.Limit).Find(&preSales).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pre-sales: %w", err)
+	}
+	return preSales, nil
+}
+
+func (r *repository) MarkPaid(ctx context.Context, preSale *PreSale, paidAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&PreSale{}).
+		Where("id = ? AND status = ?", preSale.ID, PreSaleStatusPending).
+		Updates(map[string]interface{}{
+			"status":     PreSaleStatusPaid,
+			"paid_at":    paidAt,
+			"updated_at": paidAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark pre-sale paid: %w", err)
+	}
+	if err := r.db.WithContext(ctx).First(preSale, "id = ?", preSale.ID).Error; err != nil {
+		return fmt.Errorf("failed to reload pre-sale: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListHeldFestivals(ctx context.Context, scope clock.Scope) ([]Festival, error) {
+	query := `
+		SELECT f.id, f.start_date, f.timezone, f.day_starts_at
+		FROM festivals f
+		WHERE EXISTS (SELECT 1 FROM wallet_presales ps WHERE ps.festival_id = f.id AND ps.status = ?)`
+	args := []interface{}{PreSaleStatusPaid}
+	if scope.FestivalID != nil {
+		query += " AND f.id = ?"
+		args = append(args, *scope.FestivalID)
+	}
+	if len(scope.Exclude) > 0 {
+		query += " AND f.id NOT IN ?"
+		args = append(args, scope.Exclude)
+	}
+
+	var festivals []Festival
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&festivals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list festivals with held pre-sales: %w", err)
+	}
+	return festivals, nil
+}
+
+func (r *repository) ListHeld(ctx context.Context, festivalID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&PreSale{}).
+		Where("festival_id = ? AND status = ?", festivalID, PreSaleStatusPaid).
+		Order("paid_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held pre-sales: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *repository) Activate(ctx context.Context, id uuid.UUID, now time.Time) (*PreSale, error) {
+	var activated PreSale
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var preSale PreSale
+		if err := tx.Raw("SELECT * FROM wallet_presales WHERE id = ? FOR UPDATE", id).Scan(&preSale).Error; err != nil {
+			return fmt.Errorf("failed to lock pre-sale: %w", err)
+		}
+		if preSale.ID == uuid.Nil {
+			return ErrPreSaleNotFound
+		}
+		if preSale.Status != PreSaleStatusPaid {
+			activated = preSale
+			return nil
+		}
+
+		var w wallet.Wallet
+		if err := tx.Raw("SELECT * FROM wallets WHERE id = ? FOR UPDATE", preSale.WalletID).Scan(&w).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+		if w.ID == uuid.Nil || w.Status != wallet.WalletStatusActive {
+			return ErrWalletNotActive
+		}
+
+		topUp := &wallet.Transaction{
+			ID:            uuid.New(),
+			WalletID:      w.ID,
+			Type:          wallet.TransactionTypeTopUp,
+			Amount:        preSale.Amount,
+			BalanceBefore: w.Balance,
+			BalanceAfter:  w.Balance + preSale.Amount,
+			Reference:     preSale.StripeIntentID,
+			Metadata: wallet.TransactionMeta{
+				Description:   "Pre-sale top-up",
+				PaymentMethod: "stripe",
+			},
+			Status:    wallet.TransactionStatusCompleted,
+			CreatedAt: now,
+		}
+		if err := moveBalance(tx, &w, topUp); err != nil {
+			return err
+		}
+		preSale.TopUpTransactionID = &topUp.ID
+
+		if preSale.BonusAmount > 0 {
+			// The bonus is not cash: an organizer credit, kept apart from the Stripe top-up
+			bonus := &wallet.Transaction{
+				ID:            uuid.New(),
+				WalletID:      w.ID,
+				Type:          wallet.TransactionTypeAdjustment,
+				Amount:        preSale.BonusAmount,
+				BalanceBefore: w.Balance,
+				BalanceAfter:  w.Balance + preSale.BonusAmount,
+				Reference:     "presale:" + preSale.ID.String(),
+				Metadata: wallet.TransactionMeta{
+					Description: "Pre-sale bonus",
+				},
+				Status:    wallet.TransactionStatusCompleted,
+				CreatedAt: now,
+			}
+			if err := moveBalance(tx, &w, bonus); err != nil {
+				return err
+			}
+			preSale.BonusTransactionID = &bonus.ID
+		}
+
+		preSale.Status = PreSaleStatusActivated
+		preSale.ActivatedAt = &now
+		preSale.UpdatedAt = now
+		if err := tx.Save(&preSale).Error; err != nil {
+			return fmt.Errorf("failed to activate pre-sale: %w", err)
+		}
+		activated = preSale
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &activated, nil
+}
+
+// moveBalance applies a transaction to a wallet locked by the transaction and records it
+func moveBalance(tx *gorm.DB, w *wallet.Wallet, txData *wallet.Transaction) error {
+	result := tx.Model(&wallet.Wallet{}).
+		Where("id = ? AND balance = ?", w.ID, w.Balance).
+		Updates(map[string]interface{}{
+			"balance":    txData.BalanceAfter,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update balance: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("concurrent modification detected, please retry")
+	}
+	if err := tx.Create(txData).Error; err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	w.Balance = txData.BalanceAfter
+	return nil
+}
+
+// preSaleIntents are the Stripe payments of pre-sale top-ups, told apart from the
+// other top-ups by their reference
+const preSaleIntents = `EXISTS (SELECT 1 FROM wallet_presales ps WHERE ps.stripe_intent_id = t.reference)`
+
+func (r *repository) GetReport(ctx context.Context, festivalID uuid.UUID, gatesOpenAt time.Time) (*Report, error) {
+	report := &Report{FestivalID: festivalID, GatesOpenAt: gatesOpenAt, Campaigns: []CampaignTotals{}}
+	db := r.db.WithContext(ctx)
+	args := map[string]interface{}{"festival": festivalID, "gates": gatesOpenAt}
+
+	err := db.Raw(`
+		SELECT
+			COUNT(*) AS top_ups,
+			COALESCE(SUM(amount), 0) AS cash_received,
+			COALESCE(SUM(bonus_amount), 0) AS bonus_granted,
+			COALESCE(SUM(amount) FILTER (WHERE status = 'PAID'), 0) AS held,
+			COALESCE(SUM(amount) FILTER (WHERE status = 'ACTIVATED'), 0) AS activated
+		FROM wallet_presales
+		WHERE festival_id = @festival AND status IN ('PAID', 'ACTIVATED')`, args).
+		Scan(&report.PreSale).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pre-sale totals: %w", err)
+	}
+
+	var other struct {
+		OtherTopUps int64
+		TicketSales int64
+	}
+	err = db.Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(t.amount), 0)
+				FROM transactions t
+				INNER JOIN wallets w ON w.id = t.wallet_id
+				WHERE w.festival_id = @festival AND t.type IN ('TOP_UP', 'CASH_IN') AND t.status = 'COMPLETED'
+					AND t.created_at < @gates AND NOT `+preSaleIntents+`) AS other_top_ups,
+			(SELECT COALESCE(SUM(pi.ticket_amount), 0)
+				FROM payment_intents pi
+				WHERE pi.festival_id = @festival AND pi.status = 'SUCCEEDED'
+					AND pi.completed_at < @gates) AS ticket_sales`, args).
+		Scan(&other).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales before the gates opened: %w", err)
+	}
+	report.PreSale.OtherTopUps = other.OtherTopUps
+	report.PreSale.TicketSales = other.TicketSales
+	report.PreSale.CashBeforeGate = report.PreSale.CashReceived + other.OtherTopUps + other.TicketSales
+
+	err = db.Raw(`
+		SELECT
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'TOP_UP' AND COALESCE(t.metadata->>'paymentMethod', '') <> 'cash'), 0) AS card_top_ups,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'CASH_IN' OR (t.type = 'TOP_UP' AND t.metadata->>'paymentMethod' = 'cash')), 0) AS cash_top_ups,
+			COALESCE(-SUM(t.amount) FILTER (WHERE t.type = 'PURCHASE'), 0) AS sales
+		FROM transactions t
+		INNER JOIN wallets w ON w.id = t.wallet_id
+		WHERE w.festival_id = @festival AND t.status = 'COMPLETED' AND t.created_at >= @gates
+			AND NOT `+preSaleIntents, args).
+		Scan(&report.OnSite).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-site totals: %w", err)
+	}
+	report.OnSite.TopUps = report.OnSite.CardTopUps + report.OnSite.CashTopUps
+
+	err = db.Raw(`
+		SELECT c.id AS campaign_id, c.name,
+			COUNT(ps.id) AS top_ups,
+			COALESCE(SUM(ps.amount), 0) AS cash_received,
+			COALESCE(SUM(ps.bonus_amount), 0) AS bonus_granted
+		FROM presale_campaigns c
+		LEFT JOIN wallet_presales ps ON ps.campaign_id = c.id AND ps.status IN ('PAID', 'ACTIVATED')
+		WHERE c.festival_id = @festival
+		GROUP BY c.id, c.name
+		ORDER BY cash_received DESC, c.name`, args).
+		Scan(&report.Campaigns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign totals: %w", err)
+	}
+
+	return report, nil
+}
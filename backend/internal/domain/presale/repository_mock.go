@@ -0,0 +1,110 @@
+package presale
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateCampaign(ctx context.Context, campaign *Campaign) error {
+	args := m.Called(ctx, campaign)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateCampaign(ctx context.Context, campaign *Campaign) error {
+	args := m.Called(ctx, campaign)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCampaign(ctx context.Context, festivalID, id uuid.UUID) (*Campaign, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Campaign), args.Error(1)
+}
+
+func (m *MockRepository) ListCampaigns(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Campaign, error) {
+	args := m.Called(ctx, festivalID, activeOnly)
+	return args.Get(0).([]Campaign), args.Error(1)
+}
+
+func (m *MockRepository) GetFestival(ctx context.Context, id uuid.UUID) (*Festival, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Festival), args.Error(1)
+}
+
+func (m *MockRepository) CreatePreSale(ctx context.Context, preSale *PreSale) error {
+	args := m.Called(ctx, preSale)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPreSale(ctx context.Context, festivalID, id uuid.UUID) (*PreSale, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PreSale), args.Error(1)
+}
+
+func (m *MockRepository) GetPreSaleByIntent(ctx context.Context, stripeIntentID string) (*PreSale, error) {
+	args := m.Called(ctx, stripeIntentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PreSale), args.Error(1)
+}
+
+func (m *MockRepository) ListPreSales(ctx context.Context, festivalID uuid.UUID, filter PreSaleFilter) ([]PreSale, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]PreSale), args.Error(1)
+}
+
+func (m *MockRepository) MarkPaid(ctx context.Context, preSale *PreSale, paidAt time.Time) error {
+	args := m.Called(ctx, preSale, paidAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListHeldFestivals(ctx context.Context, scope clock.Scope) ([]Festival, error) {
+	args := m.Called(ctx, scope)
+	return args.Get(0).([]Festival), args.Error(1)
+}
+
+func (m *MockRepository) ListHeld(ctx context.Context, festivalID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	args := m.Called(ctx, festivalID, limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) Activate(ctx context.Context, id uuid.UUID, now time.Time) (*PreSale, error) {
+	args := m.Called(ctx, id, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PreSale), args.Error(1)
+}
+
+func (m *MockRepository) GetReport(ctx context.Context, festivalID uuid.UUID, gatesOpenAt time.Time) (*Report, error) {
+	args := m.Called(ctx, festivalID, gatesOpenAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Report), args.Error(1)
+}
@@ -0,0 +1,378 @@
+package presale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeActivatePreSales is the worker task crediting the pre-sale top-ups of the festivals
+// whose gates opened
+const TypeActivatePreSales = "presale:activate"
+
+// activationBatch bounds the pre-sales of a festival activated by one run
+const activationBatch = 500
+
+// Wallets finds the wallet a pre-sale credits, satisfied by *wallet.Service
+type Wallets interface {
+	GetOrCreateWallet(ctx context.Context, userID, festivalID uuid.UUID) (*wallet.Wallet, error)
+}
+
+// PaymentGateway charges pre-sale top-ups by card, satisfied by *payment.Service
+type PaymentGateway interface {
+	CreatePreSalePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, amount int64, email string, metadata map[string]string) (stripeIntentID, clientSecret string, err error)
+}
+
+// Service sells wallet top-ups before the festival gates open, with the bonus of the
+// best running campaign. The money paid is held until the gates open, when the top-up
+// and its bonus are credited together, so that pre-sale balances cannot be spent, or
+// counted as on-site revenue, before the festival starts.
+type Service struct {
+	repo     Repository
+	wallets  Wallets
+	payments PaymentGateway
+	clock    clock.Clock
+}
+
+// NewService creates the pre-sale service
+func NewService(repo Repository, wallets Wallets) *Service {
+	return &Service{
+		repo:    repo,
+		wallets: wallets,
+		clock:   clock.Wall{},
+	}
+}
+
+// SetPaymentGateway charges pre-sale top-ups by card; without it they cannot be bought
+func (s *Service) SetPaymentGateway(payments PaymentGateway) {
+	s.payments = payments
+}
+
+// SetClock sets the clock telling the time of festivals, so that the gates of sandbox
+// festivals open at their virtual time
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// GatesOpenAt returns when the gates of a festival open: the start of its first
+// operational day
+func GatesOpenAt(festival *Festival) time.Time {
+	start, _ := tz.NewCalendar(festival.Timezone, festival.DayStartsAt).Bounds(festival.StartDate)
+	return start
+}
+
+// CreateCampaign adds a pre-sale campaign to the festival
+func (s *Service) CreateCampaign(ctx context.Context, festivalID uuid.UUID, req CreateCampaignRequest) (*Campaign, error) {
+	if _, err := s.getFestival(ctx, festivalID); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now(ctx, festivalID)
+	campaign := &Campaign{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		Name:        strings.TrimSpace(req.Name),
+		MinAmount:   req.MinAmount,
+		BonusAmount: req.BonusAmount,
+		Repeat:      req.Repeat,
+		StartsAt:    now,
+		EndsAt:      req.EndsAt,
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.StartsAt != nil {
+		campaign.StartsAt = *req.StartsAt
+	}
+	if campaign.EndsAt != nil && !campaign.EndsAt.After(campaign.StartsAt) {
+		return nil, ErrCampaignPeriod
+	}
+
+	if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// UpdateCampaign changes a campaign. The pre-sales already started keep their bonus.
+func (s *Service) UpdateCampaign(ctx context.Context, festivalID, id uuid.UUID, req UpdateCampaignRequest) (*Campaign, error) {
+	campaign, err := s.repo.GetCampaign(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, ErrCampaignNotFound
+	}
+
+	if req.Name != nil {
+		campaign.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.MinAmount != nil {
+		campaign.MinAmount = *req.MinAmount
+	}
+	if req.BonusAmount != nil {
+		campaign.BonusAmount = *req.BonusAmount
+	}
+	if req.Repeat != nil {
+		campaign.Repeat = *req.Repeat
+	}
+	if req.StartsAt != nil {
+		campaign.StartsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		campaign.EndsAt = req.EndsAt
+	}
+	if req.Active != nil {
+		campaign.Active = *req.Active
+	}
+	if campaign.EndsAt != nil && !campaign.EndsAt.After(campaign.StartsAt) {
+		return nil, ErrCampaignPeriod
+	}
+	campaign.UpdatedAt = s.clock.Now(ctx, festivalID)
+
+	if err := s.repo.UpdateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// ListCampaigns lists the campaigns of the festival
+func (s *Service) ListCampaigns(ctx context.Context, festivalID uuid.UUID) ([]Campaign, error) {
+	return s.repo.ListCampaigns(ctx, festivalID, false)
+}
+
+// Quote returns what a pre-sale top-up of amount is credited when the gates open
+func (s *Service) Quote(ctx context.Context, festivalID uuid.UUID, amount int64) (*Offer, error) {
+	festival, err := s.getFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now(ctx, festivalID)
+	gatesOpenAt := GatesOpenAt(festival)
+	if !now.Before(gatesOpenAt) {
+		return nil, ErrPreSaleClosed
+	}
+
+	campaigns, err := s.repo.ListCampaigns(ctx, festivalID, true)
+	if err != nil {
+		return nil, err
+	}
+	offer := &Offer{Amount: amount, ActivatesAt: gatesOpenAt}
+	if campaign := bestCampaign(campaigns, amount, now); campaign != nil {
+		offer.BonusAmount = campaign.Bonus(amount)
+		offer.CampaignID = &campaign.ID
+		offer.Campaign = campaign.Name
+	}
+	return offer, nil
+}
+
+// bestCampaign returns the running campaign giving the largest bonus on amount, nil when
+// none gives any
+func bestCampaign(campaigns []Campaign, amount int64, now time.Time) *Campaign {
+	var best *Campaign
+	for i := range campaigns {
+		campaign := &campaigns[i]
+		if !campaign.Running(now) || campaign.Bonus(amount) == 0 {
+			continue
+		}
+		if best == nil || campaign.Bonus(amount) > best.Bonus(amount) {
+			best = campaign
+		}
+	}
+	return best
+}
+
+// Checkout starts a pre-sale top-up by an attendee: it creates the card payment, which
+// the app confirms with the client secret, with the bonus of the best running campaign.
+// The wallet is credited when the gates open.
+func (s *Service) Checkout(ctx context.Context, festivalID, userID uuid.UUID, email string, req CheckoutRequest) (*Checkout, error) {
+	if s.payments == nil {
+		return nil, ErrPaymentsUnavailable
+	}
+	offer, err := s.Quote(ctx, festivalID, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := s.wallets.GetOrCreateWallet(ctx, userID, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Status != wallet.WalletStatusActive {
+		return nil, ErrWalletNotActive
+	}
+
+	preSaleID := uuid.New()
+	metadata := map[string]string{
+		"presale_id":   preSaleID.String(),
+		"bonus_amount": fmt.Sprintf("%d", offer.BonusAmount),
+		"activates_at": offer.ActivatesAt.Format(time.RFC3339),
+	}
+	if offer.CampaignID != nil {
+		metadata["campaign_id"] = offer.CampaignID.String()
+	}
+	intentID, clientSecret, err := s.payments.CreatePreSalePaymentIntent(ctx, festivalID, userID, w.ID, req.Amount, email, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now(ctx, festivalID)
+	preSale := &PreSale{
+		ID:             preSaleID,
+		FestivalID:     festivalID,
+		UserID:         userID,
+		WalletID:       w.ID,
+		CampaignID:     offer.CampaignID,
+		StripeIntentID: intentID,
+		Amount:         req.Amount,
+		BonusAmount:    offer.BonusAmount,
+		Status:         PreSaleStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.repo.CreatePreSale(ctx, preSale); err != nil {
+		return nil, err
+	}
+	return &Checkout{PreSale: *preSale, ClientSecret: clientSecret, ActivatesAt: offer.ActivatesAt}, nil
+}
+
+// FulfillPayment holds the top-up of the pre-sale paid by a succeeded payment intent
+// until the gates open, once whatever the webhook retries. A payment confirmed after the
+// gates opened is credited right away. An error asks the webhook to be retried.
+func (s *Service) FulfillPayment(ctx context.Context, stripeIntentID string) error {
+	preSale, err := s.repo.GetPreSaleByIntent(ctx, stripeIntentID)
+	if err != nil {
+		return err
+	}
+	if preSale == nil {
+		// The checkout may not have saved the pre-sale yet
+		return ErrPreSaleNotFound
+	}
+	if preSale.Status != PreSaleStatusPending {
+		return nil
+	}
+
+	now := s.clock.Now(ctx, preSale.FestivalID)
+	if err := s.repo.MarkPaid(ctx, preSale, now); err != nil {
+		return err
+	}
+
+	festival, err := s.getFestival(ctx, preSale.FestivalID)
+	if err != nil {
+		return err
+	}
+	if now.Before(GatesOpenAt(festival)) {
+		log.Info().
+			Str("presale_id", preSale.ID.String()).
+			Int64("amount", preSale.Amount).
+			Int64("bonus_amount", preSale.BonusAmount).
+			Msg("Pre-sale top-up paid, held until the gates open")
+		return nil
+	}
+
+	// Left paid when the wallet cannot be credited; the activation job retries it
+	if _, err := s.repo.Activate(ctx, preSale.ID, now); err != nil && !errors.Is(err, ErrWalletNotActive) {
+		return err
+	}
+	return nil
+}
+
+// HandleActivatePreSales is the worker handler of TypeActivatePreSales
+func (s *Service) HandleActivatePreSales(ctx context.Context, t *asynq.Task) error {
+	activated, err := s.ActivateDue(ctx)
+	if err != nil {
+		return err
+	}
+	if activated > 0 {
+		log.Info().Int("presales", activated).Msg("Credited pre-sale top-ups at the gates opening")
+	}
+	return nil
+}
+
+// ActivateDue credits the paid pre-sales of the festivals whose gates opened, returning
+// how many. Festivals on a test clock are due at their virtual time. A pre-sale whose
+// wallet is frozen is left held until the wallet is unfrozen.
+func (s *Service) ActivateDue(ctx context.Context) (int, error) {
+	runs, err := clock.Runs(ctx, s.clock, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get test clocks: %w", err)
+	}
+
+	activated := 0
+	for _, run := range runs {
+		festivals, err := s.repo.ListHeldFestivals(ctx, run.Scope)
+		if err != nil {
+			return activated, err
+		}
+		for i := range festivals {
+			if run.Now.Before(GatesOpenAt(&festivals[i])) {
+				continue
+			}
+			ids, err := s.repo.ListHeld(ctx, festivals[i].ID, activationBatch)
+			if err != nil {
+				return activated, err
+			}
+			for _, id := range ids {
+				preSale, err := s.repo.Activate(ctx, id, run.Now)
+				if errors.Is(err, ErrWalletNotActive) {
+					continue
+				}
+				if err != nil {
+					log.Error().Err(err).Str("presale_id", id.String()).Msg("Failed to credit pre-sale top-up")
+					continue
+				}
+				if preSale.Status == PreSaleStatusActivated {
+					activated++
+				}
+			}
+		}
+	}
+	return activated, nil
+}
+
+// GetPreSale returns a pre-sale of the festival
+func (s *Service) GetPreSale(ctx context.Context, festivalID, id uuid.UUID) (*PreSale, error) {
+	preSale, err := s.repo.GetPreSale(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if preSale == nil {
+		return nil, ErrPreSaleNotFound
+	}
+	return preSale, nil
+}
+
+// ListPreSales lists the pre-sales of the festival, latest first
+func (s *Service) ListPreSales(ctx context.Context, festivalID uuid.UUID, filter PreSaleFilter) ([]PreSale, error) {
+	return s.repo.ListPreSales(ctx, festivalID, filter)
+}
+
+// GetReport splits the money the festival received before its gates opened, pre-sale
+// top-ups and ticket sales, from the money received on site
+func (s *Service) GetReport(ctx context.Context, festivalID uuid.UUID) (*Report, error) {
+	festival, err := s.getFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReport(ctx, festivalID, GatesOpenAt(festival))
+}
+
+func (s *Service) getFestival(ctx context.Context, id uuid.UUID) (*Festival, error) {
+	festival, err := s.repo.GetFestival(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+	return festival, nil
+}
@@ -0,0 +1,303 @@
+package presale
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWallets struct {
+	wallets map[uuid.UUID]*wallet.Wallet // By user
+}
+
+func (w *fakeWallets) GetOrCreateWallet(ctx context.Context, userID, festivalID uuid.UUID) (*wallet.Wallet, error) {
+	if found, ok := w.wallets[userID]; ok {
+		return found, nil
+	}
+	created := &wallet.Wallet{ID: uuid.New(), UserID: &userID, FestivalID: festivalID, Status: wallet.WalletStatusActive}
+	w.wallets[userID] = created
+	return created, nil
+}
+
+type fakePayments struct {
+	intents  int
+	amount   int64
+	metadata map[string]string
+}
+
+func (p *fakePayments) CreatePreSalePaymentIntent(ctx context.Context, festivalID, userID, walletID uuid.UUID, amount int64, email string, metadata map[string]string) (string, string, error) {
+	p.intents++
+	p.amount = amount
+	p.metadata = metadata
+	return "pi_" + uuid.NewString(), "secret", nil
+}
+
+// Gates open on 2026-07-17 at 10:00 in Brussels, 08:00 UTC
+var gatesOpenAt = time.Date(2026, 7, 17, 8, 0, 0, 0, time.UTC)
+
+type testEnv struct {
+	service    *Service
+	mockRepo   *MockRepository
+	wallets    *fakeWallets
+	payments   *fakePayments
+	festival   *Festival
+	festivalID uuid.UUID
+	now        clock.Fixed
+}
+
+func newTestEnv(t *testing.T, now time.Time) *testEnv {
+	t.Helper()
+	mockRepo := NewMockRepository()
+	festivalID := uuid.New()
+	festival := &Festival{
+		ID:          festivalID,
+		StartDate:   time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+		Timezone:    "Europe/Brussels",
+		DayStartsAt: "10:00",
+	}
+	mockRepo.On("GetFestival", mock.Anything, festivalID).Return(festival, nil).Maybe()
+
+	wallets := &fakeWallets{wallets: make(map[uuid.UUID]*wallet.Wallet)}
+	payments := &fakePayments{}
+	service := NewService(mockRepo, wallets)
+	service.SetPaymentGateway(payments)
+	fixed := clock.Fixed{festivalID: now}
+	service.SetClock(fixed)
+
+	return &testEnv{service: service, mockRepo: mockRepo, wallets: wallets, payments: payments, festival: festival, festivalID: festivalID, now: fixed}
+}
+
+func (e *testEnv) addCampaign(t *testing.T, req CreateCampaignRequest) *Campaign {
+	t.Helper()
+	e.mockRepo.On("CreateCampaign", mock.Anything, mock.AnythingOfType("*presale.Campaign")).Return(nil).Once()
+	campaign, err := e.service.CreateCampaign(context.Background(), e.festivalID, req)
+	require.NoError(t, err)
+	return campaign
+}
+
+// expectCampaigns returns the active campaigns of the festival on the next quote
+func (e *testEnv) expectCampaigns(campaigns ...*Campaign) {
+	var active []Campaign
+	for _, campaign := range campaigns {
+		active = append(active, *campaign)
+	}
+	e.mockRepo.On("ListCampaigns", mock.Anything, e.festivalID, true).Return(active, nil).Once()
+}
+
+// checkout starts a pre-sale top-up, which the repository returns from then on by
+// payment intent
+func (e *testEnv) checkout(t *testing.T, userID uuid.UUID, amount int64) *PreSale {
+	t.Helper()
+	stored := &PreSale{}
+	e.mockRepo.On("CreatePreSale", mock.Anything, mock.AnythingOfType("*presale.PreSale")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*PreSale) }).
+		Return(nil).Once()
+
+	_, err := e.service.Checkout(context.Background(), e.festivalID, userID, "fan@example.com", CheckoutRequest{Amount: amount})
+	require.NoError(t, err)
+	e.mockRepo.On("GetPreSaleByIntent", mock.Anything, stored.StripeIntentID).Return(stored, nil).Maybe()
+	return stored
+}
+
+// expectMarkPaid holds the top-up of a pre-sale once its payment succeeded
+func (e *testEnv) expectMarkPaid(preSale *PreSale) {
+	e.mockRepo.On("MarkPaid", mock.Anything, preSale, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			paidAt := args.Get(2).(time.Time)
+			preSale.Status = PreSaleStatusPaid
+			preSale.PaidAt = &paidAt
+		}).
+		Return(nil).Once()
+}
+
+// expectActivate credits the wallet of a paid pre-sale
+func (e *testEnv) expectActivate(preSale *PreSale) {
+	e.mockRepo.On("Activate", mock.Anything, preSale.ID, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			activatedAt := args.Get(2).(time.Time)
+			preSale.Status = PreSaleStatusActivated
+			preSale.ActivatedAt = &activatedAt
+		}).
+		Return(preSale, nil).Once()
+}
+
+// expectHeld returns the paid pre-sales of the festival, which runs on its test clock,
+// on the next activation pass
+func (e *testEnv) expectHeld(ids ...uuid.UUID) {
+	var festivals []Festival
+	if len(ids) > 0 {
+		festivals = []Festival{*e.festival}
+	}
+	e.mockRepo.On("ListHeldFestivals", mock.Anything, mock.MatchedBy(func(scope clock.Scope) bool {
+		return scope.FestivalID == nil
+	})).Return([]Festival(nil), nil).Once()
+	e.mockRepo.On("ListHeldFestivals", mock.Anything, clock.Scope{FestivalID: &e.festivalID}).Return(festivals, nil).Once()
+	if len(ids) > 0 {
+		e.mockRepo.On("ListHeld", mock.Anything, e.festivalID, activationBatch).Return(ids, nil).Once().Maybe()
+	}
+}
+
+func TestCampaign_Bonus(t *testing.T) {
+	once := Campaign{MinAmount: 5000, BonusAmount: 500}
+	assert.Zero(t, once.Bonus(4999))
+	assert.Equal(t, int64(500), once.Bonus(5000))
+	assert.Equal(t, int64(500), once.Bonus(12000))
+
+	repeat := Campaign{MinAmount: 5000, BonusAmount: 500, Repeat: true}
+	assert.Equal(t, int64(500), repeat.Bonus(9999))
+	assert.Equal(t, int64(1000), repeat.Bonus(12000))
+}
+
+func TestGatesOpenAt(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt)
+	assert.True(t, gatesOpenAt.Equal(GatesOpenAt(env.festival)))
+}
+
+func TestCheckout_BestCampaign(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt.Add(-48*time.Hour))
+	ctx := context.Background()
+	early := env.addCampaign(t, CreateCampaignRequest{Name: "Early bird", MinAmount: 5000, BonusAmount: 500})
+	best := env.addCampaign(t, CreateCampaignRequest{Name: "Every 20", MinAmount: 2000, BonusAmount: 200, Repeat: true})
+	ended := gatesOpenAt.Add(-72 * time.Hour)
+	started := ended.Add(-time.Hour)
+	over := env.addCampaign(t, CreateCampaignRequest{Name: "Over", MinAmount: 1000, BonusAmount: 5000, StartsAt: &started, EndsAt: &ended})
+	env.expectCampaigns(early, best, over)
+	env.expectCampaigns(early, best, over)
+	env.mockRepo.On("CreatePreSale", mock.Anything, mock.AnythingOfType("*presale.PreSale")).Return(nil).Twice()
+
+	userID := uuid.New()
+	checkout, err := env.service.Checkout(ctx, env.festivalID, userID, "fan@example.com", CheckoutRequest{Amount: 6000})
+	require.NoError(t, err)
+
+	assert.Equal(t, PreSaleStatusPending, checkout.PreSale.Status)
+	assert.Equal(t, int64(6000), checkout.PreSale.Amount)
+	assert.Equal(t, int64(600), checkout.PreSale.BonusAmount)
+	assert.Equal(t, &best.ID, checkout.PreSale.CampaignID)
+	assert.True(t, gatesOpenAt.Equal(checkout.ActivatesAt))
+	assert.Equal(t, int64(6000), env.payments.amount)
+	assert.Equal(t, "600", env.payments.metadata["bonus_amount"])
+
+	// Under every minimum there is no bonus
+	checkout, err = env.service.Checkout(ctx, env.festivalID, userID, "fan@example.com", CheckoutRequest{Amount: 1500})
+	require.NoError(t, err)
+	assert.Zero(t, checkout.PreSale.BonusAmount)
+	assert.Nil(t, checkout.PreSale.CampaignID)
+	env.mockRepo.AssertExpectations(t)
+}
+
+func TestCheckout_GatesOpen(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt)
+
+	_, err := env.service.Checkout(context.Background(), env.festivalID, uuid.New(), "", CheckoutRequest{Amount: 5000})
+	assert.ErrorIs(t, err, ErrPreSaleClosed)
+	assert.Zero(t, env.payments.intents)
+	env.mockRepo.AssertNotCalled(t, "ListCampaigns", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckout_WalletFrozen(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt.Add(-time.Hour))
+	userID := uuid.New()
+	env.wallets.wallets[userID] = &wallet.Wallet{ID: uuid.New(), Status: wallet.WalletStatusFrozen}
+	env.expectCampaigns()
+
+	_, err := env.service.Checkout(context.Background(), env.festivalID, userID, "", CheckoutRequest{Amount: 5000})
+	assert.ErrorIs(t, err, ErrWalletNotActive)
+	assert.Zero(t, env.payments.intents)
+	env.mockRepo.AssertNotCalled(t, "CreatePreSale", mock.Anything, mock.Anything)
+}
+
+func TestFulfillPayment_HeldUntilGatesOpen(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt.Add(-24*time.Hour))
+	ctx := context.Background()
+	early := env.addCampaign(t, CreateCampaignRequest{Name: "Early bird", MinAmount: 5000, BonusAmount: 500})
+	env.expectCampaigns(early)
+	preSale := env.checkout(t, uuid.New(), 5000)
+
+	env.expectMarkPaid(preSale)
+	require.NoError(t, env.service.FulfillPayment(ctx, preSale.StripeIntentID))
+	assert.Equal(t, PreSaleStatusPaid, preSale.Status)
+	env.mockRepo.AssertNotCalled(t, "Activate", mock.Anything, mock.Anything, mock.Anything)
+
+	// Webhook retries are ignored
+	require.NoError(t, env.service.FulfillPayment(ctx, preSale.StripeIntentID))
+	env.mockRepo.AssertNumberOfCalls(t, "MarkPaid", 1)
+
+	// Held until the gates open
+	env.expectHeld(preSale.ID)
+	activated, err := env.service.ActivateDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, activated)
+	env.mockRepo.AssertNotCalled(t, "ListHeld", mock.Anything, mock.Anything, mock.Anything)
+
+	env.now[env.festivalID] = gatesOpenAt
+	env.expectHeld(preSale.ID)
+	env.expectActivate(preSale)
+	activated, err = env.service.ActivateDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, activated)
+	assert.Equal(t, PreSaleStatusActivated, preSale.Status)
+
+	env.expectHeld()
+	activated, err = env.service.ActivateDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, activated)
+	env.mockRepo.AssertNumberOfCalls(t, "Activate", 1)
+}
+
+func TestFulfillPayment_AfterGatesOpen(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt.Add(-time.Minute))
+	ctx := context.Background()
+	env.expectCampaigns()
+	preSale := env.checkout(t, uuid.New(), 3000)
+
+	// The payment is confirmed once the gates are open
+	env.now[env.festivalID] = gatesOpenAt.Add(time.Minute)
+	env.expectMarkPaid(preSale)
+	env.expectActivate(preSale)
+	require.NoError(t, env.service.FulfillPayment(ctx, preSale.StripeIntentID))
+	assert.Equal(t, PreSaleStatusActivated, preSale.Status)
+	env.mockRepo.AssertExpectations(t)
+}
+
+func TestActivateDue_FrozenWalletStaysHeld(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt.Add(-time.Hour))
+	ctx := context.Background()
+	env.expectCampaigns()
+	preSale := env.checkout(t, uuid.New(), 3000)
+	env.expectMarkPaid(preSale)
+	require.NoError(t, env.service.FulfillPayment(ctx, preSale.StripeIntentID))
+
+	env.now[env.festivalID] = gatesOpenAt
+	env.expectHeld(preSale.ID)
+	env.mockRepo.On("Activate", mock.Anything, preSale.ID, gatesOpenAt).Return(nil, ErrWalletNotActive).Once()
+	activated, err := env.service.ActivateDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, activated)
+	assert.Equal(t, PreSaleStatusPaid, preSale.Status)
+
+	// The wallet was unfrozen
+	env.expectHeld(preSale.ID)
+	env.expectActivate(preSale)
+	activated, err = env.service.ActivateDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, activated)
+	env.mockRepo.AssertExpectations(t)
+}
+
+func TestCreateCampaign_Period(t *testing.T) {
+	env := newTestEnv(t, gatesOpenAt.Add(-time.Hour))
+	startsAt := gatesOpenAt.Add(-48 * time.Hour)
+	endsAt := startsAt
+
+	_, err := env.service.CreateCampaign(context.Background(), env.festivalID, CreateCampaignRequest{
+		Name: "Empty", MinAmount: 5000, BonusAmount: 500, StartsAt: &startsAt, EndsAt: &endsAt,
+	})
+	assert.ErrorIs(t, err, ErrCampaignPeriod)
+}
@@ -62,7 +62,7 @@ func (h *Handler) Create(c *gin.Context) {
 
 	var req CreatePricingRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -183,7 +183,7 @@ func (h *Handler) Update(c *gin.Context) {
 
 	var req UpdatePricingRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
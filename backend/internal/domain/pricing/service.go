@@ -11,9 +11,16 @@ import (
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
 )
 
+// ChangeSequencer counts the price changes the POS terminals compare to know their
+// cached prices are stale; satisfied by posdevice.Sequencer
+type ChangeSequencer interface {
+	StandPricesChanged(ctx context.Context, standID uuid.UUID)
+}
+
 type Service struct {
 	repo        Repository
 	productRepo product.Repository
+	sequencer   ChangeSequencer
 }
 
 func NewService(repo Repository, productRepo product.Repository) *Service {
@@ -23,6 +30,18 @@ func NewService(repo Repository, productRepo product.Repository) *Service {
 	}
 }
 
+// SetChangeSequencer counts the pricing rule edits for the POS terminals
+func (s *Service) SetChangeSequencer(sequencer ChangeSequencer) {
+	s.sequencer = sequencer
+}
+
+// pricesChanged reports a rule edit of a stand to the sequencer
+func (s *Service) pricesChanged(ctx context.Context, standID uuid.UUID) {
+	if s.sequencer != nil {
+		s.sequencer.StandPricesChanged(ctx, standID)
+	}
+}
+
 // Create creates a new pricing rule
 func (s *Service) Create(ctx context.Context, standID uuid.UUID, req CreatePricingRuleRequest) (*PricingRule, error) {
 	// Validate time format
@@ -71,6 +90,7 @@ func (s *Service) Create(ctx context.Context, standID uuid.UUID, req CreatePrici
 	if err := s.repo.Create(ctx, rule); err != nil {
 		return nil, fmt.Errorf("failed to create pricing rule: %w", err)
 	}
+	s.pricesChanged(ctx, rule.StandID)
 
 	return rule, nil
 }
@@ -166,6 +186,7 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdatePricingRul
 	if err := s.repo.Update(ctx, rule); err != nil {
 		return nil, fmt.Errorf("failed to update pricing rule: %w", err)
 	}
+	s.pricesChanged(ctx, rule.StandID)
 
 	return rule, nil
 }
@@ -180,7 +201,11 @@ func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 		return errors.ErrNotFound
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.pricesChanged(ctx, rule.StandID)
+	return nil
 }
 
 // GetCurrentDiscounts gets all currently active discounts for a stand
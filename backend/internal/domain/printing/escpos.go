@@ -0,0 +1,250 @@
+package printing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ESC/POS commands understood by Epson compatible thermal printers
+var (
+	escInit         = []byte{0x1B, 0x40}        // ESC @
+	escCodePage1252 = []byte{0x1B, 0x74, 16}    // ESC t 16, WPC1252
+	escAlignLeft    = []byte{0x1B, 0x61, 0}     // ESC a 0
+	escAlignCenter  = []byte{0x1B, 0x61, 1}     // ESC a 1
+	escBoldOn       = []byte{0x1B, 0x45, 1}     // ESC E 1
+	escBoldOff      = []byte{0x1B, 0x45, 0}     // ESC E 0
+	escSizeNormal   = []byte{0x1D, 0x21, 0x00}  // GS ! 0
+	escSizeDouble   = []byte{0x1D, 0x21, 0x11}  // GS ! double width and height
+	escSizeTall     = []byte{0x1D, 0x21, 0x01}  // GS ! double height
+	escFeedCut      = []byte{0x1D, 0x56, 66, 3} // GS V 66 n, feed n lines and partial cut
+)
+
+// TicketLine is an item line of an order ticket
+type TicketLine struct {
+	Quantity  int
+	Name      string
+	Allergens []string // Labels of the allergens declared on the product
+}
+
+// TicketField is a custom field of an order printed on its ticket, e.g. a table number
+type TicketField struct {
+	Label string
+	Value string
+}
+
+// OrderTicket is the content of the ticket printed for a paid order
+type OrderTicket struct {
+	Number        string // Short order number called out at the counter
+	StandName     string
+	PaidAt        time.Time // In the festival time zone
+	Lines         []TicketLine
+	Fields        []TicketField
+	Notes         string
+	PaymentMethod string
+	Total         string // Formatted total, empty to leave it out
+	RoundUp       string // Formatted round-up donation, empty to leave it out
+	Charity       string // Charity of the round-up
+	Reprint       bool   // Marks tickets of corrected orders
+}
+
+// escposWriter accumulates ESC/POS commands and CP1252 text
+type escposWriter struct {
+	buf     bytes.Buffer
+	columns int
+}
+
+func newESCPOSWriter(width PaperWidth) *escposWriter {
+	w := &escposWriter{columns: width.Columns()}
+	w.buf.Write(escInit)
+	w.buf.Write(escCodePage1252)
+	return w
+}
+
+func (w *escposWriter) command(cmd []byte) {
+	w.buf.Write(cmd)
+}
+
+// line writes text followed by a line feed
+func (w *escposWriter) line(text string) {
+	w.buf.Write(encodeText(text))
+	w.buf.WriteByte('\n')
+}
+
+// wrapped writes text wrapped to the paper width, indenting continuation lines
+func (w *escposWriter) wrapped(text string, columns, indent int) {
+	for i, l := range wrapText(text, columns-indent) {
+		if i > 0 {
+			l = strings.Repeat(" ", indent) + l
+		}
+		w.line(l)
+	}
+}
+
+func (w *escposWriter) rule() {
+	w.line(strings.Repeat("-", w.columns))
+}
+
+func (w *escposWriter) bytes() []byte {
+	w.buf.Write(escFeedCut)
+	return w.buf.Bytes()
+}
+
+// RenderOrderTicket renders the kitchen and counter ticket of an order
+func RenderOrderTicket(ticket OrderTicket, width PaperWidth) []byte {
+	w := newESCPOSWriter(width)
+
+	w.command(escAlignCenter)
+	w.command(escSizeDouble)
+	// Double width halves the characters per line
+	w.wrapped(ticket.Number, w.columns/2, 0)
+	w.command(escSizeNormal)
+	if ticket.Reprint {
+		w.line("CORRECTED ORDER")
+	}
+	w.command(escBoldOn)
+	w.wrapped(ticket.StandName, w.columns, 0)
+	w.command(escBoldOff)
+	w.line(ticket.PaidAt.Format("02/01/2006 15:04"))
+
+	w.command(escAlignLeft)
+	w.rule()
+	w.command(escSizeTall)
+	for _, item := range ticket.Lines {
+		qty := fmt.Sprintf("%d x ", item.Quantity)
+		indent := utf8.RuneCountInString(qty)
+		w.wrapped(qty+item.Name, w.columns, indent)
+		if len(item.Allergens) > 0 {
+			// In normal size under the item name, so that the items stand out
+			w.command(escSizeNormal)
+			for _, l := range wrapText("Allergens: "+strings.Join(item.Allergens, ", "), w.columns-indent) {
+				w.line(strings.Repeat(" ", indent) + l)
+			}
+			w.command(escSizeTall)
+		}
+	}
+	w.command(escSizeNormal)
+	w.rule()
+
+	if len(ticket.Fields) > 0 {
+		for _, field := range ticket.Fields {
+			w.wrapped(field.Label+": "+field.Value, w.columns, 0)
+		}
+		w.rule()
+	}
+
+	if ticket.Notes != "" {
+		w.command(escBoldOn)
+		w.line("Notes:")
+		w.command(escBoldOff)
+		w.wrapped(ticket.Notes, w.columns, 0)
+		w.rule()
+	}
+
+	if ticket.Total != "" {
+		w.line(padBetween("Total", ticket.Total, w.columns))
+	}
+	if ticket.PaymentMethod != "" {
+		w.line(padBetween("Paid", ticket.PaymentMethod, w.columns))
+	}
+	if ticket.RoundUp != "" {
+		w.line(padBetween("Round-up", ticket.RoundUp, w.columns))
+		w.wrapped("Donated to "+ticket.Charity+", thank you!", w.columns, 0)
+	}
+
+	return w.bytes()
+}
+
+// RenderTestPage renders the page printed to check a printer setup
+func RenderTestPage(printer *Printer, at time.Time) []byte {
+	w := newESCPOSWriter(printer.PaperWidth)
+
+	w.command(escAlignCenter)
+	w.command(escSizeDouble)
+	w.line("TEST")
+	w.command(escSizeNormal)
+	w.command(escBoldOn)
+	w.wrapped(printer.Name, w.columns, 0)
+	w.command(escBoldOff)
+	w.line(at.Format("02/01/2006 15:04:05"))
+
+	w.command(escAlignLeft)
+	w.rule()
+	w.line(padBetween("Connection", string(printer.Connection), w.columns))
+	if printer.Address != "" {
+		w.line(padBetween("Address", printer.Address, w.columns))
+	}
+	w.line(padBetween("Paper", fmt.Sprintf("%d mm", printer.PaperWidth), w.columns))
+	w.rule()
+	w.line("àéèêç ÀÉ ÄÖÜß ñ € £")
+	w.line(strings.Repeat("0123456789", w.columns/10+1)[:w.columns])
+
+	return w.bytes()
+}
+
+// encodeText converts text to the WPC1252 code page selected on the printer, replacing
+// characters it cannot print
+func encodeText(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 0x20 {
+			r = ' '
+		}
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			b = '?'
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// wrapText splits text into lines of at most columns characters, breaking on spaces
+func wrapText(text string, columns int) []string {
+	if columns < 1 {
+		columns = 1
+	}
+
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > columns {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(runes[:columns]))
+			runes = runes[columns:]
+		}
+		switch {
+		case len(current) == 0:
+			current = runes
+		case len(current)+1+len(runes) <= columns:
+			current = append(append(current, ' '), runes...)
+		default:
+			lines = append(lines, string(current))
+			current = runes
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	return lines
+}
+
+// padBetween lays out a label and a value on both ends of a line
+func padBetween(label, value string, columns int) string {
+	gap := columns - utf8.RuneCountInString(label) - utf8.RuneCountInString(value)
+	if gap < 1 {
+		gap = 1
+	}
+	return label + strings.Repeat(" ", gap) + value
+}
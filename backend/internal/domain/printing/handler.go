@@ -0,0 +1,473 @@
+package printing
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped printer and print job routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	printers := r.Group("/printers")
+	{
+		printers.GET("", h.ListPrinters)
+		printers.POST("", h.CreatePrinter)
+		printers.PATCH("/:printerId", h.UpdatePrinter)
+		printers.DELETE("/:printerId", h.DeletePrinter)
+		printers.POST("/:printerId/token", h.RotateAgentToken)
+		printers.POST("/:printerId/test", h.PrintTestPage)
+	}
+
+	jobs := r.Group("/print-jobs")
+	{
+		jobs.GET("", h.ListJobs)
+		jobs.GET("/summary", h.JobSummary)
+		jobs.POST("/:jobId/retry", h.RetryJob)
+	}
+}
+
+// RegisterAgentRoutes registers the routes of the print agents, authenticated with
+// the agent token of their printer
+func (h *Handler) RegisterAgentRoutes(r *gin.RouterGroup) {
+	agent := r.Group("")
+	agent.Use(h.authenticateAgent)
+	{
+		agent.GET("/jobs", h.PollJobs)
+		agent.POST("/jobs/:jobId/ack", h.AckJob)
+	}
+}
+
+// ListPrinters lists the printers of the festival
+// @Summary List printers
+// @Description List the stand printers with whether their print agent polled in the last 2 minutes
+// @Tags printing
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the printers of this stand" format(uuid)
+// @Success 200 {object} response.Response{data=[]PrinterResponse} "Printers"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/printers [get]
+func (h *Handler) ListPrinters(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return
+	}
+
+	printers, err := h.service.ListPrinters(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, printers)
+}
+
+// CreatePrinter registers a printer of a stand
+// @Summary Register printer
+// @Description Register a network or USB thermal printer of a stand. Paid orders of the stand are printed on its enabled printers. The agent token is only returned once.
+// @Tags printing
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreatePrinterRequest true "Printer"
+// @Success 201 {object} response.Response{data=PrinterWithToken} "Printer registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid printer"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/printers [post]
+func (h *Handler) CreatePrinter(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreatePrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	printer, err := h.service.CreatePrinter(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, printer)
+}
+
+// UpdatePrinter updates a printer
+// @Summary Update printer
+// @Description Update the connection, paper width or copies of a printer, or disable it
+// @Tags printing
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param printerId path string true "Printer ID" format(uuid)
+// @Param request body UpdatePrinterRequest true "Printer changes"
+// @Success 200 {object} response.Response{data=PrinterResponse} "Printer updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid printer"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Printer not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/printers/{printerId} [patch]
+func (h *Handler) UpdatePrinter(c *gin.Context) {
+	festivalID, printerID, ok := printerParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	printer, err := h.service.UpdatePrinter(c.Request.Context(), festivalID, printerID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, printer)
+}
+
+// DeletePrinter deletes a printer
+// @Summary Delete printer
+// @Description Delete a printer with its print jobs
+// @Tags printing
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param printerId path string true "Printer ID" format(uuid)
+// @Success 204 "Printer deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Printer not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/printers/{printerId} [delete]
+func (h *Handler) DeletePrinter(c *gin.Context) {
+	festivalID, printerID, ok := printerParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePrinter(c.Request.Context(), festivalID, printerID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// RotateAgentToken replaces the agent token of a printer
+// @Summary Rotate print agent token
+// @Description Issue a new agent token for a printer; the previous token stops working immediately
+// @Tags printing
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param printerId path string true "Printer ID" format(uuid)
+// @Success 200 {object} response.Response{data=PrinterWithToken} "New agent token"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Printer not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/printers/{printerId}/token [post]
+func (h *Handler) RotateAgentToken(c *gin.Context) {
+	festivalID, printerID, ok := printerParams(c)
+	if !ok {
+		return
+	}
+
+	printer, err := h.service.RotateAgentToken(c.Request.Context(), festivalID, printerID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, printer)
+}
+
+// PrintTestPage queues a test page
+// @Summary Print test page
+// @Description Queue a test page showing the printer settings and accented characters
+// @Tags printing
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param printerId path string true "Printer ID" format(uuid)
+// @Success 201 {object} response.Response{data=PrintJob} "Test page queued"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Printer not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/printers/{printerId}/test [post]
+func (h *Handler) PrintTestPage(c *gin.Context) {
+	festivalID, printerID, ok := printerParams(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.PrintTestPage(c.Request.Context(), festivalID, printerID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, job)
+}
+
+// ListJobs lists the print jobs of the festival
+// @Summary List print jobs
+// @Description List print jobs latest first, e.g. the failed ones with status=FAILED
+// @Tags printing
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Job status" Enums(PENDING, PRINTING, PRINTED, FAILED)
+// @Param standId query string false "Stand ID" format(uuid)
+// @Param printerId query string false "Printer ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Success 200 {object} response.Response{data=[]PrintJob} "Print jobs"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/print-jobs [get]
+func (h *Handler) ListJobs(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query ListJobsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+	var ok bool
+	if query.StandID, ok = optionalUUID(c, "standId"); !ok {
+		return
+	}
+	if query.PrinterID, ok = optionalUUID(c, "printerId"); !ok {
+		return
+	}
+
+	jobs, total, err := h.service.ListJobs(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, jobs, &response.Meta{
+		Total:   int(total),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// JobSummary counts the print jobs of the festival per status
+// @Summary Print job summary
+// @Description Count the print jobs per status, to flag failed prints on the dashboard
+// @Tags printing
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=JobCounts} "Jobs per status"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/print-jobs/summary [get]
+func (h *Handler) JobSummary(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	counts, err := h.service.CountJobs(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, counts)
+}
+
+// RetryJob queues a failed print job again
+// @Summary Retry print job
+// @Description Queue a failed print job again with a fresh set of attempts
+// @Tags printing
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param jobId path string true "Print job ID" format(uuid)
+// @Success 200 {object} response.Response{data=PrintJob} "Job queued"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Print job not found"
+// @Failure 409 {object} response.ErrorResponse "Job has not failed"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/print-jobs/{jobId}/retry [post]
+func (h *Handler) RetryJob(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid print job ID", nil)
+		return
+	}
+
+	job, err := h.service.RetryJob(c.Request.Context(), festivalID, jobID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, job)
+}
+
+// PollJobs delivers the due jobs of the agent's printer
+// @Summary Poll print jobs
+// @Description Long poll used by print agents: claims the due jobs of the printer, waiting up to 8 seconds for one. Each job must be acknowledged before ackBefore or it is delivered again.
+// @Tags printing
+// @Produce json
+// @Param Authorization header string true "Bearer agent token"
+// @Param wait query int false "Seconds to wait for a job (max 8)" default(0)
+// @Success 200 {object} response.Response{data=[]AgentJob} "Jobs, possibly none"
+// @Failure 401 {object} response.ErrorResponse "Invalid agent token"
+// @Router /print-agent/jobs [get]
+func (h *Handler) PollJobs(c *gin.Context) {
+	var query PollQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	jobs, err := h.service.PollJobs(c.Request.Context(), agentPrinter(c), time.Duration(query.Wait)*time.Second)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, jobs)
+}
+
+// AckJob reports the outcome of a print job
+// @Summary Acknowledge print job
+// @Description Report whether a job was printed. Failed jobs are delivered again with an exponential backoff, then marked FAILED.
+// @Tags printing
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer agent token"
+// @Param jobId path string true "Print job ID" format(uuid)
+// @Param request body AckRequest true "Outcome"
+// @Success 200 {object} response.Response{data=PrintJob} "Job updated"
+// @Failure 401 {object} response.ErrorResponse "Invalid agent token"
+// @Failure 404 {object} response.ErrorResponse "Print job not found"
+// @Failure 409 {object} response.ErrorResponse "Job is not being printed"
+// @Router /print-agent/jobs/{jobId}/ack [post]
+func (h *Handler) AckJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid print job ID", nil)
+		return
+	}
+
+	var req AckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	job, err := h.service.AckJob(c.Request.Context(), agentPrinter(c), jobID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, job)
+}
+
+// authenticateAgent resolves the printer of the bearer agent token
+func (h *Handler) authenticateAgent(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	printer, err := h.service.AuthenticateAgent(c.Request.Context(), token)
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set("printer", printer)
+	c.Next()
+}
+
+func agentPrinter(c *gin.Context) *Printer {
+	return c.MustGet("printer").(*Printer)
+}
+
+func printerParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	printerID, err := uuid.Parse(c.Param("printerId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid printer ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, printerID, true
+}
+
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid "+name, nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidAgentToken):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, ErrPrinterNotFound):
+		response.NotFound(c, "Printer not found")
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	case errors.Is(err, ErrJobNotFound):
+		response.NotFound(c, "Print job not found")
+	case errors.Is(err, ErrInvalidConnection), errors.Is(err, ErrInvalidAddress), errors.Is(err, ErrInvalidPaperWidth):
+		response.BadRequest(c, "INVALID_PRINTER", err.Error(), nil)
+	case errors.Is(err, ErrInvalidStatus):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrJobNotRetryable):
+		response.Conflict(c, "JOB_NOT_FAILED", err.Error())
+	case errors.Is(err, ErrJobNotLeased):
+		response.Conflict(c, "JOB_NOT_LEASED", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,228 @@
+package printing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Printing errors
+var (
+	ErrPrinterNotFound   = errors.New("printer not found")
+	ErrStandNotFound     = errors.New("stand not found")
+	ErrJobNotFound       = errors.New("print job not found")
+	ErrInvalidConnection = errors.New("connection must be NETWORK or USB")
+	ErrInvalidAddress    = errors.New("network printers need a host or host:port address")
+	ErrInvalidPaperWidth = errors.New("paper width must be 58 or 80 mm")
+	ErrInvalidStatus     = errors.New("unknown print job status")
+	ErrJobNotRetryable   = errors.New("only failed print jobs can be retried")
+	ErrJobNotLeased      = errors.New("print job is not being printed by this printer")
+	ErrInvalidAgentToken = errors.New("invalid print agent token")
+)
+
+// Job delivery and retries
+const (
+	DefaultMaxAttempts = 5
+	LeaseDuration      = time.Minute      // Time an agent has to acknowledge a claimed job
+	RetryBaseDelay     = 10 * time.Second // Delay before the first retry, doubled after each attempt
+	RetryMaxDelay      = 5 * time.Minute
+	MaxPollWait        = 8 * time.Second // Stays under the write timeout of the API server
+	MaxJobsPerPoll     = 10
+	OnlineWindow       = 2 * time.Minute // Printers polled within this window are shown online
+	DefaultNetworkPort = "9100"
+	AgentTokenPrefix   = "prt_"
+)
+
+// Connection is how the print agent reaches the printer
+type Connection string
+
+const (
+	ConnectionNetwork Connection = "NETWORK" // Raw TCP (JetDirect) to Address, usually port 9100
+	ConnectionUSB     Connection = "USB"     // Printer attached to the agent, Address is the device path
+)
+
+// IsValid checks if the connection is valid
+func (c Connection) IsValid() bool {
+	return c == ConnectionNetwork || c == ConnectionUSB
+}
+
+// PaperWidth is the roll width of a thermal printer in millimetres
+type PaperWidth int
+
+const (
+	PaperWidth58 PaperWidth = 58
+	PaperWidth80 PaperWidth = 80
+)
+
+// IsValid checks if the paper width is supported
+func (w PaperWidth) IsValid() bool {
+	return w == PaperWidth58 || w == PaperWidth80
+}
+
+// Columns returns the characters per line of font A
+func (w PaperWidth) Columns() int {
+	if w == PaperWidth58 {
+		return 32
+	}
+	return 48
+}
+
+// Printer is a thermal printer of a stand, driven by a print agent
+type Printer struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID     uuid.UUID  `json:"standId" gorm:"type:uuid;not null;index"`
+	Name        string     `json:"name" gorm:"not null"`
+	Connection  Connection `json:"connection" gorm:"not null"`
+	Address     string     `json:"address,omitempty"` // host:port of network printers, device path of USB printers
+	PaperWidth  PaperWidth `json:"paperWidth" gorm:"not null;default:80"`
+	Copies      int        `json:"copies" gorm:"not null;default:1"`
+	Enabled     bool       `json:"enabled" gorm:"not null;default:true"`
+	TokenHash   string     `json:"-" gorm:"not null;uniqueIndex"`
+	TokenPrefix string     `json:"tokenPrefix"` // Identifies the agent token without revealing it
+	LastSeenAt  *time.Time `json:"lastSeenAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Printer) TableName() string {
+	return "printers"
+}
+
+// Online reports whether the agent of the printer polled recently
+func (p *Printer) Online(now time.Time) bool {
+	return p.LastSeenAt != nil && now.Sub(*p.LastSeenAt) < OnlineWindow
+}
+
+// JobStatus is the delivery state of a print job
+type JobStatus string
+
+const (
+	JobStatusPending  JobStatus = "PENDING"  // Waiting for the agent, possibly until NextAttemptAt
+	JobStatusPrinting JobStatus = "PRINTING" // Claimed by the agent until LeaseExpiresAt
+	JobStatusPrinted  JobStatus = "PRINTED"
+	JobStatusFailed   JobStatus = "FAILED" // Out of attempts, retried manually
+)
+
+// IsValid checks if the job status is valid
+func (s JobStatus) IsValid() bool {
+	switch s {
+	case JobStatusPending, JobStatusPrinting, JobStatusPrinted, JobStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// JobKind is what a print job prints
+type JobKind string
+
+const (
+	JobKindOrder JobKind = "ORDER" // Order ticket of a paid order
+	JobKindTest  JobKind = "TEST"  // Test page requested from the admin
+)
+
+// PrintJob is an ESC/POS document queued for a printer
+type PrintJob struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID     uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID        uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	PrinterID      uuid.UUID  `json:"printerId" gorm:"type:uuid;not null"`
+	OrderID        *uuid.UUID `json:"orderId,omitempty" gorm:"type:uuid"`
+	Kind           JobKind    `json:"kind" gorm:"not null"`
+	Status         JobStatus  `json:"status" gorm:"not null;default:'PENDING'"`
+	Payload        []byte     `json:"-" gorm:"type:bytea;not null"` // ESC/POS commands
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts    int        `json:"maxAttempts" gorm:"not null"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt,omitempty"`
+	PrintedAt      *time.Time `json:"printedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+func (PrintJob) TableName() string {
+	return "print_jobs"
+}
+
+// retryDelay returns the wait before the next attempt of a job that failed attempts times
+func retryDelay(attempts int) time.Duration {
+	delay := RetryBaseDelay
+	for i := 1; i < attempts && delay < RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > RetryMaxDelay {
+		return RetryMaxDelay
+	}
+	return delay
+}
+
+// CreatePrinterRequest represents the request to register a printer
+type CreatePrinterRequest struct {
+	StandID    uuid.UUID  `json:"standId" binding:"required"`
+	Name       string     `json:"name" binding:"required,max=100"`
+	Connection Connection `json:"connection" binding:"required"`
+	Address    string     `json:"address" binding:"max=255"`
+	PaperWidth PaperWidth `json:"paperWidth"` // Defaults to 80
+	Copies     int        `json:"copies" binding:"omitempty,min=1,max=5"`
+}
+
+// UpdatePrinterRequest represents the request to update a printer
+type UpdatePrinterRequest struct {
+	Name       *string     `json:"name,omitempty" binding:"omitempty,max=100"`
+	Connection *Connection `json:"connection,omitempty"`
+	Address    *string     `json:"address,omitempty" binding:"omitempty,max=255"`
+	PaperWidth *PaperWidth `json:"paperWidth,omitempty"`
+	Copies     *int        `json:"copies,omitempty" binding:"omitempty,min=1,max=5"`
+	Enabled    *bool       `json:"enabled,omitempty"`
+}
+
+// PrinterResponse is a printer with its agent status
+type PrinterResponse struct {
+	Printer
+	Online bool `json:"online"`
+}
+
+// PrinterWithToken is returned once when a printer is created or its token rotated
+type PrinterWithToken struct {
+	PrinterResponse
+	AgentToken string `json:"agentToken"`
+}
+
+// ListJobsQuery filters the print jobs of the admin view
+type ListJobsQuery struct {
+	Status    JobStatus  `form:"status"`
+	StandID   *uuid.UUID `form:"-"` // Parsed from the standId query parameter
+	PrinterID *uuid.UUID `form:"-"` // Parsed from the printerId query parameter
+	Page      int        `form:"page,default=1" binding:"min=1"`
+	PerPage   int        `form:"per_page,default=50" binding:"min=1,max=200"`
+}
+
+// JobCounts counts the jobs of a festival per status
+type JobCounts map[JobStatus]int64
+
+// PollQuery is the long-poll request of a print agent
+type PollQuery struct {
+	Wait int `form:"wait" binding:"min=0"` // Seconds to wait for a job, capped at MaxPollWait
+}
+
+// AgentJob is a print job delivered to an agent. Payload is base64 encoded in JSON.
+type AgentJob struct {
+	ID         uuid.UUID  `json:"id"`
+	OrderID    *uuid.UUID `json:"orderId,omitempty"`
+	Kind       JobKind    `json:"kind"`
+	Connection Connection `json:"connection"`
+	Address    string     `json:"address,omitempty"`
+	Copies     int        `json:"copies"`
+	Attempt    int        `json:"attempt"`
+	Payload    []byte     `json:"payload"`
+	AckBefore  time.Time  `json:"ackBefore"`
+}
+
+// AckRequest reports the outcome of a job to the API
+type AckRequest struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty" binding:"max=500"`
+}
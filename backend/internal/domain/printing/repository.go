@@ -0,0 +1,311 @@
+package printing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StandInfo is what an order ticket shows of the stand and its festival
+type StandInfo struct {
+	ID           uuid.UUID
+	FestivalID   uuid.UUID
+	Name         string
+	Timezone     string
+	CurrencyName string
+	ExchangeRate float64
+}
+
+type Repository interface {
+	CreatePrinter(ctx context.Context, printer *Printer) error
+	GetPrinter(ctx context.Context, festivalID, id uuid.UUID) (*Printer, error)
+	GetPrinterByTokenHash(ctx context.Context, tokenHash string) (*Printer, error)
+	ListPrinters(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Printer, error)
+	ListEnabledPrinters(ctx context.Context, standID uuid.UUID) ([]Printer, error)
+	UpdatePrinter(ctx context.Context, printer *Printer) error
+	DeletePrinter(ctx context.Context, id uuid.UUID) error
+	TouchPrinter(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	CreateJobs(ctx context.Context, jobs []PrintJob) error
+	GetJob(ctx context.Context, festivalID, id uuid.UUID) (*PrintJob, error)
+	GetPrinterJob(ctx context.Context, printerID, id uuid.UUID) (*PrintJob, error)
+	UpdateJob(ctx context.Context, job *PrintJob) error
+	ListJobs(ctx context.Context, festivalID uuid.UUID, query ListJobsQuery) ([]PrintJob, int64, error)
+	CountJobs(ctx context.Context, festivalID uuid.UUID) (JobCounts, error)
+	ClaimJobs(ctx context.Context, printerID uuid.UUID, now time.Time, limit int) ([]PrintJob, error)
+	FailExpiredJobs(ctx context.Context, now time.Time) (int64, error)
+
+	GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreatePrinter(ctx context.Context, printer *Printer) error {
+	if err := r.db.WithContext(ctx).Create(printer).Error; err != nil {
+		return fmt.Errorf("failed to create printer: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetPrinter(ctx context.Context, festivalID, id uuid.UUID) (*Printer, error) {
+	var printer Printer
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&printer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get printer: %w", err)
+	}
+	return &printer, nil
+}
+
+func (r *repository) GetPrinterByTokenHash(ctx context.Context, tokenHash string) (*Printer, error) {
+	var printer Printer
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&printer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get printer: %w", err)
+	}
+	return &printer, nil
+}
+
+func (r *repository) ListPrinters(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Printer, error) {
+	var printers []Printer
+	q := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		q = q.Where("stand_id = ?", *standID)
+	}
+	if err := q.Order("stand_id, name").Find(&printers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list printers: %w", err)
+	}
+	return printers, nil
+}
+
+func (r *repository) ListEnabledPrinters(ctx context.Context, standID uuid.UUID) ([]Printer, error) {
+	var printers []Printer
+	err := r.db.WithContext(ctx).
+		Where("stand_id = ? AND enabled", standID).
+		Order("name").
+		Find(&printers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stand printers: %w", err)
+	}
+	return printers, nil
+}
+
+func (r *repository) UpdatePrinter(ctx context.Context, printer *Printer) error {
+	if err := r.db.WithContext(ctx).Save(printer).Error; err != nil {
+		return fmt.Errorf("failed to update printer: %w", err)
+	}
+	return nil
+}
+
+// DeletePrinter deletes a printer with its print jobs
+func (r *repository) DeletePrinter(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("printer_id = ?", id).Delete(&PrintJob{}).Error; err != nil {
+			return fmt.Errorf("failed to delete print jobs: %w", err)
+		}
+		if err := tx.Delete(&Printer{}, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to delete printer: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) TouchPrinter(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Printer{}).
+		Where("id = ?", id).
+		UpdateColumn("last_seen_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to update printer: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) CreateJobs(ctx context.Context, jobs []PrintJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&jobs).Error; err != nil {
+		return fmt.Errorf("failed to create print jobs: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetJob(ctx context.Context, festivalID, id uuid.UUID) (*PrintJob, error) {
+	var job PrintJob
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get print job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *repository) GetPrinterJob(ctx context.Context, printerID, id uuid.UUID) (*PrintJob, error) {
+	var job PrintJob
+	err := r.db.WithContext(ctx).Where("id = ? AND printer_id = ?", id, printerID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get print job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *repository) UpdateJob(ctx context.Context, job *PrintJob) error {
+	if err := r.db.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update print job: %w", err)
+	}
+	return nil
+}
+
+// ListJobs lists print jobs without their payload, latest first
+func (r *repository) ListJobs(ctx context.Context, festivalID uuid.UUID, query ListJobsQuery) ([]PrintJob, int64, error) {
+	var jobs []PrintJob
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&PrintJob{}).Where("festival_id = ?", festivalID)
+	if query.Status != "" {
+		q = q.Where("status = ?", query.Status)
+	}
+	if query.StandID != nil {
+		q = q.Where("stand_id = ?", *query.StandID)
+	}
+	if query.PrinterID != nil {
+		q = q.Where("printer_id = ?", *query.PrinterID)
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count print jobs: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	err := q.Omit("payload").
+		Offset(offset).
+		Limit(query.PerPage).
+		Order("created_at DESC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list print jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+func (r *repository) CountJobs(ctx context.Context, festivalID uuid.UUID) (JobCounts, error) {
+	var rows []struct {
+		Status JobStatus
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&PrintJob{}).
+		Select("status, COUNT(*) AS count").
+		Where("festival_id = ?", festivalID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count print jobs: %w", err)
+	}
+
+	counts := JobCounts{
+		JobStatusPending:  0,
+		JobStatusPrinting: 0,
+		JobStatusPrinted:  0,
+		JobStatusFailed:   0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// ClaimJobs leases the due jobs of a printer, oldest first. Jobs whose lease expired
+// without an acknowledgement are claimed again while they have attempts left.
+func (r *repository) ClaimJobs(ctx context.Context, printerID uuid.UUID, now time.Time, limit int) ([]PrintJob, error) {
+	var jobs []PrintJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("printer_id = ?", printerID).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND lease_expires_at < ? AND attempts < max_attempts)",
+				JobStatusPending, now, JobStatusPrinting, now).
+			Order("created_at").
+			Limit(limit).
+			Find(&jobs).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim print jobs: %w", err)
+		}
+
+		leaseExpiresAt := now.Add(LeaseDuration)
+		for i := range jobs {
+			jobs[i].Status = JobStatusPrinting
+			jobs[i].Attempts++
+			jobs[i].LeaseExpiresAt = &leaseExpiresAt
+			jobs[i].UpdatedAt = now
+			err := tx.Model(&PrintJob{}).Where("id = ?", jobs[i].ID).Updates(map[string]interface{}{
+				"status":           jobs[i].Status,
+				"attempts":         jobs[i].Attempts,
+				"lease_expires_at": leaseExpiresAt,
+				"updated_at":       now,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to lease print job: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// FailExpiredJobs fails the jobs whose last attempt was never acknowledged
+func (r *repository) FailExpiredJobs(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&PrintJob{}).
+		Where("status = ? AND lease_expires_at < ? AND attempts >= max_attempts", JobStatusPrinting, now).
+		Updates(map[string]interface{}{
+			"status":           JobStatusFailed,
+			"last_error":       "print agent did not acknowledge the job",
+			"lease_expires_at": nil,
+			"updated_at":       now,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to fail expired print jobs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *repository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	var infos []StandInfo
+	err := r.db.WithContext(ctx).
+		Table("stands s").
+		Select("s.id, s.festival_id, s.name, f.timezone, f.currency_name, f.exchange_rate").
+		Joins("JOIN festivals f ON f.id = s.festival_id").
+		Where("s.id = ?", standID).
+		Limit(1).
+		Scan(&infos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	return &infos[0], nil
+}
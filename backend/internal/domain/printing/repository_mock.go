@@ -0,0 +1,121 @@
+package printing
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreatePrinter(ctx context.Context, printer *Printer) error {
+	args := m.Called(ctx, printer)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPrinter(ctx context.Context, festivalID, id uuid.UUID) (*Printer, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Printer), args.Error(1)
+}
+
+func (m *MockRepository) GetPrinterByTokenHash(ctx context.Context, tokenHash string) (*Printer, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Printer), args.Error(1)
+}
+
+func (m *MockRepository) ListPrinters(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Printer, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]Printer), args.Error(1)
+}
+
+func (m *MockRepository) ListEnabledPrinters(ctx context.Context, standID uuid.UUID) ([]Printer, error) {
+	args := m.Called(ctx, standID)
+	return args.Get(0).([]Printer), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePrinter(ctx context.Context, printer *Printer) error {
+	args := m.Called(ctx, printer)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeletePrinter(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchPrinter(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateJobs(ctx context.Context, jobs []PrintJob) error {
+	args := m.Called(ctx, jobs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetJob(ctx context.Context, festivalID, id uuid.UUID) (*PrintJob, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PrintJob), args.Error(1)
+}
+
+func (m *MockRepository) GetPrinterJob(ctx context.Context, printerID, id uuid.UUID) (*PrintJob, error) {
+	args := m.Called(ctx, printerID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PrintJob), args.Error(1)
+}
+
+func (m *MockRepository) UpdateJob(ctx context.Context, job *PrintJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListJobs(ctx context.Context, festivalID uuid.UUID, query ListJobsQuery) ([]PrintJob, int64, error) {
+	args := m.Called(ctx, festivalID, query)
+	return args.Get(0).([]PrintJob), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) CountJobs(ctx context.Context, festivalID uuid.UUID) (JobCounts, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).(JobCounts), args.Error(1)
+}
+
+func (m *MockRepository) ClaimJobs(ctx context.Context, printerID uuid.UUID, now time.Time, limit int) ([]PrintJob, error) {
+	args := m.Called(ctx, printerID, now, limit)
+	return args.Get(0).([]PrintJob), args.Error(1)
+}
+
+func (m *MockRepository) FailExpiredJobs(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	args := m.Called(ctx, standID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandInfo), args.Error(1)
+}
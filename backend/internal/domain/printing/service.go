@@ -0,0 +1,563 @@
+package printing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeSweepJobs is the worker task failing print jobs never acknowledged
+const TypeSweepJobs = "printing:sweep"
+
+// pollInterval is how often waiting agents check for jobs when Redis is not available
+const pollInterval = time.Second
+
+// Service manages stand printers and delivers their print jobs to the print agents
+type Service struct {
+	repo  Repository
+	redis *redis.Client
+	now   func() time.Time
+}
+
+// NewService creates a printing service. Waiting agents are woken up through Redis
+// when a client is given, and poll the database every second otherwise.
+func NewService(repo Repository, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:  repo,
+		redis: redisClient,
+		now:   time.Now,
+	}
+}
+
+// CreatePrinter registers a printer of a stand and returns its agent token, which is
+// only shown once
+func (s *Service) CreatePrinter(ctx context.Context, festivalID uuid.UUID, req CreatePrinterRequest) (*PrinterWithToken, error) {
+	stand, err := s.repo.GetStandInfo(ctx, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil || stand.FestivalID != festivalID {
+		return nil, ErrStandNotFound
+	}
+
+	if req.PaperWidth == 0 {
+		req.PaperWidth = PaperWidth80
+	}
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+	address, err := validatePrinter(req.Connection, req.Address, req.PaperWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateAgentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	printer := &Printer{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		StandID:     req.StandID,
+		Name:        req.Name,
+		Connection:  req.Connection,
+		Address:     address,
+		PaperWidth:  req.PaperWidth,
+		Copies:      req.Copies,
+		Enabled:     true,
+		TokenHash:   hashAgentToken(token),
+		TokenPrefix: token[:len(AgentTokenPrefix)+6],
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreatePrinter(ctx, printer); err != nil {
+		return nil, err
+	}
+
+	return &PrinterWithToken{PrinterResponse: s.toResponse(printer), AgentToken: token}, nil
+}
+
+// ListPrinters lists the printers of a festival, optionally of one stand
+func (s *Service) ListPrinters(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]PrinterResponse, error) {
+	printers, err := s.repo.ListPrinters(ctx, festivalID, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]PrinterResponse, len(printers))
+	for i := range printers {
+		responses[i] = s.toResponse(&printers[i])
+	}
+	return responses, nil
+}
+
+// UpdatePrinter updates the settings of a printer
+func (s *Service) UpdatePrinter(ctx context.Context, festivalID, printerID uuid.UUID, req UpdatePrinterRequest) (*PrinterResponse, error) {
+	printer, err := s.getPrinter(ctx, festivalID, printerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		printer.Name = *req.Name
+	}
+	if req.Connection != nil {
+		printer.Connection = *req.Connection
+	}
+	if req.Address != nil {
+		printer.Address = *req.Address
+	}
+	if req.PaperWidth != nil {
+		printer.PaperWidth = *req.PaperWidth
+	}
+	if req.Copies != nil {
+		printer.Copies = *req.Copies
+	}
+	if req.Enabled != nil {
+		printer.Enabled = *req.Enabled
+	}
+
+	address, err := validatePrinter(printer.Connection, printer.Address, printer.PaperWidth)
+	if err != nil {
+		return nil, err
+	}
+	printer.Address = address
+	printer.UpdatedAt = s.now()
+
+	if err := s.repo.UpdatePrinter(ctx, printer); err != nil {
+		return nil, err
+	}
+
+	response := s.toResponse(printer)
+	return &response, nil
+}
+
+// RotateAgentToken replaces the agent token of a printer, disconnecting the agent
+// using the previous one
+func (s *Service) RotateAgentToken(ctx context.Context, festivalID, printerID uuid.UUID) (*PrinterWithToken, error) {
+	printer, err := s.getPrinter(ctx, festivalID, printerID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateAgentToken()
+	if err != nil {
+		return nil, err
+	}
+	printer.TokenHash = hashAgentToken(token)
+	printer.TokenPrefix = token[:len(AgentTokenPrefix)+6]
+	printer.UpdatedAt = s.now()
+
+	if err := s.repo.UpdatePrinter(ctx, printer); err != nil {
+		return nil, err
+	}
+
+	return &PrinterWithToken{PrinterResponse: s.toResponse(printer), AgentToken: token}, nil
+}
+
+// DeletePrinter deletes a printer and its print jobs
+func (s *Service) DeletePrinter(ctx context.Context, festivalID, printerID uuid.UUID) error {
+	if _, err := s.getPrinter(ctx, festivalID, printerID); err != nil {
+		return err
+	}
+	return s.repo.DeletePrinter(ctx, printerID)
+}
+
+// PrintTestPage queues a test page on a printer
+func (s *Service) PrintTestPage(ctx context.Context, festivalID, printerID uuid.UUID) (*PrintJob, error) {
+	printer, err := s.getPrinter(ctx, festivalID, printerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	job := s.newJob(printer, JobKindTest, nil, RenderTestPage(printer, now), now)
+	if err := s.repo.CreateJobs(ctx, []PrintJob{job}); err != nil {
+		return nil, err
+	}
+	s.notify(ctx, printer.ID)
+
+	return &job, nil
+}
+
+// PrintOrder queues the ticket of a paid order on the enabled printers of its stand.
+// It satisfies order.OrderPrinter.
+func (s *Service) PrintOrder(ctx context.Context, o *order.Order) error {
+	printers, err := s.repo.ListEnabledPrinters(ctx, o.StandID)
+	if err != nil {
+		return err
+	}
+	if len(printers) == 0 {
+		return nil
+	}
+
+	stand, err := s.repo.GetStandInfo(ctx, o.StandID)
+	if err != nil {
+		return err
+	}
+	if stand == nil {
+		return ErrStandNotFound
+	}
+
+	ticket := orderTicket(o, stand, s.now())
+	now := s.now()
+	jobs := make([]PrintJob, len(printers))
+	for i := range printers {
+		jobs[i] = s.newJob(&printers[i], JobKindOrder, &o.ID, RenderOrderTicket(ticket, printers[i].PaperWidth), now)
+	}
+	if err := s.repo.CreateJobs(ctx, jobs); err != nil {
+		return err
+	}
+
+	for i := range printers {
+		s.notify(ctx, printers[i].ID)
+	}
+	return nil
+}
+
+// ListJobs lists the print jobs of a festival for the admin view
+func (s *Service) ListJobs(ctx context.Context, festivalID uuid.UUID, query ListJobsQuery) ([]PrintJob, int64, error) {
+	if query.Status != "" && !query.Status.IsValid() {
+		return nil, 0, ErrInvalidStatus
+	}
+	return s.repo.ListJobs(ctx, festivalID, query)
+}
+
+// CountJobs counts the print jobs of a festival per status
+func (s *Service) CountJobs(ctx context.Context, festivalID uuid.UUID) (JobCounts, error) {
+	return s.repo.CountJobs(ctx, festivalID)
+}
+
+// RetryJob queues a failed print job again with a fresh set of attempts
+func (s *Service) RetryJob(ctx context.Context, festivalID, jobID uuid.UUID) (*PrintJob, error) {
+	job, err := s.repo.GetJob(ctx, festivalID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != JobStatusFailed {
+		return nil, ErrJobNotRetryable
+	}
+
+	now := s.now()
+	job.Status = JobStatusPending
+	job.Attempts = 0
+	job.NextAttemptAt = now
+	job.LeaseExpiresAt = nil
+	job.UpdatedAt = now
+	if err := s.repo.UpdateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	s.notify(ctx, job.PrinterID)
+
+	return job, nil
+}
+
+// AuthenticateAgent returns the printer of an agent token
+func (s *Service) AuthenticateAgent(ctx context.Context, token string) (*Printer, error) {
+	if !strings.HasPrefix(token, AgentTokenPrefix) {
+		return nil, ErrInvalidAgentToken
+	}
+
+	printer, err := s.repo.GetPrinterByTokenHash(ctx, hashAgentToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if printer == nil {
+		return nil, ErrInvalidAgentToken
+	}
+	return printer, nil
+}
+
+// PollJobs claims the due jobs of a printer, waiting up to wait for one to be queued.
+// Disabled printers are kept online but get no jobs.
+func (s *Service) PollJobs(ctx context.Context, printer *Printer, wait time.Duration) ([]AgentJob, error) {
+	if wait > MaxPollWait {
+		wait = MaxPollWait
+	}
+	if err := s.repo.TouchPrinter(ctx, printer.ID, s.now()); err != nil {
+		return nil, err
+	}
+	if !printer.Enabled {
+		return []AgentJob{}, nil
+	}
+
+	// Subscribe before the first claim so that jobs queued in between wake us up
+	var wakeups <-chan *redis.Message
+	if s.redis != nil && wait > 0 {
+		pubsub := s.redis.Subscribe(ctx, printerChannel(printer.ID))
+		defer pubsub.Close()
+		wakeups = pubsub.Channel()
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	for {
+		jobs, err := s.repo.ClaimJobs(ctx, printer.ID, s.now(), MaxJobsPerPoll)
+		if err != nil {
+			return nil, err
+		}
+		if len(jobs) > 0 || wait <= 0 {
+			return s.toAgentJobs(printer, jobs), nil
+		}
+
+		// Retries come due without a wakeup, so check at least every second
+		tick := time.NewTimer(pollInterval)
+		select {
+		case <-ctx.Done():
+			tick.Stop()
+			return []AgentJob{}, nil
+		case <-deadline.C:
+			tick.Stop()
+			return []AgentJob{}, nil
+		case <-wakeups:
+		case <-tick.C:
+		}
+		tick.Stop()
+	}
+}
+
+// AckJob records the outcome of a job reported by the agent of its printer. Failed
+// jobs are retried with an exponential backoff until they run out of attempts.
+func (s *Service) AckJob(ctx context.Context, printer *Printer, jobID uuid.UUID, req AckRequest) (*PrintJob, error) {
+	job, err := s.repo.GetPrinterJob(ctx, printer.ID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	if job.Status == JobStatusPrinted {
+		return job, nil
+	}
+	if job.Status != JobStatusPrinting {
+		return nil, ErrJobNotLeased
+	}
+
+	now := s.now()
+	job.LeaseExpiresAt = nil
+	job.UpdatedAt = now
+	switch {
+	case req.Success:
+		job.Status = JobStatusPrinted
+		job.PrintedAt = &now
+		job.LastError = ""
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = JobStatusFailed
+		job.LastError = ackError(req)
+	default:
+		job.Status = JobStatusPending
+		job.NextAttemptAt = now.Add(retryDelay(job.Attempts))
+		job.LastError = ackError(req)
+	}
+
+	if err := s.repo.UpdateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	if job.Status == JobStatusFailed {
+		log.Warn().
+			Str("job_id", job.ID.String()).
+			Str("printer_id", printer.ID.String()).
+			Str("error", job.LastError).
+			Msg("Print job failed after all attempts")
+	}
+
+	return job, nil
+}
+
+// HandleSweepJobs fails the print jobs whose last attempt was never acknowledged, so
+// that they show up in the admin view
+func (s *Service) HandleSweepJobs(ctx context.Context, t *asynq.Task) error {
+	failed, err := s.repo.FailExpiredJobs(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		log.Warn().Int64("jobs", failed).Msg("Failed print jobs never acknowledged by their agent")
+	}
+	return nil
+}
+
+func (s *Service) getPrinter(ctx context.Context, festivalID, printerID uuid.UUID) (*Printer, error) {
+	printer, err := s.repo.GetPrinter(ctx, festivalID, printerID)
+	if err != nil {
+		return nil, err
+	}
+	if printer == nil {
+		return nil, ErrPrinterNotFound
+	}
+	return printer, nil
+}
+
+func (s *Service) newJob(printer *Printer, kind JobKind, orderID *uuid.UUID, payload []byte, now time.Time) PrintJob {
+	return PrintJob{
+		ID:            uuid.New(),
+		FestivalID:    printer.FestivalID,
+		StandID:       printer.StandID,
+		PrinterID:     printer.ID,
+		OrderID:       orderID,
+		Kind:          kind,
+		Status:        JobStatusPending,
+		Payload:       payload,
+		MaxAttempts:   DefaultMaxAttempts,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// notify wakes up the agent of a printer waiting for jobs
+func (s *Service) notify(ctx context.Context, printerID uuid.UUID) {
+	if s.redis == nil {
+		return
+	}
+	if err := s.redis.Publish(ctx, printerChannel(printerID), "job").Err(); err != nil {
+		log.Warn().Err(err).Str("printer_id", printerID.String()).Msg("Failed to wake up print agent")
+	}
+}
+
+func (s *Service) toResponse(printer *Printer) PrinterResponse {
+	return PrinterResponse{Printer: *printer, Online: printer.Online(s.now())}
+}
+
+func (s *Service) toAgentJobs(printer *Printer, jobs []PrintJob) []AgentJob {
+	agentJobs := make([]AgentJob, len(jobs))
+	for i, job := range jobs {
+		agentJobs[i] = AgentJob{
+			ID:         job.ID,
+			OrderID:    job.OrderID,
+			Kind:       job.Kind,
+			Connection: printer.Connection,
+			Address:    printer.Address,
+			Copies:     printer.Copies,
+			Attempt:    job.Attempts,
+			Payload:    job.Payload,
+			AckBefore:  *job.LeaseExpiresAt,
+		}
+	}
+	return agentJobs
+}
+
+// orderTicket lays out the ticket of an order in the festival time zone
+func orderTicket(o *order.Order, stand *StandInfo, now time.Time) OrderTicket {
+	paidAt := o.UpdatedAt
+	if paidAt.IsZero() {
+		paidAt = now
+	}
+	if loc, err := time.LoadLocation(stand.Timezone); err == nil {
+		paidAt = paidAt.In(loc)
+	}
+
+	lines := make([]TicketLine, len(o.Items))
+	for i, item := range o.Items {
+		lines[i] = TicketLine{Quantity: item.Quantity, Name: item.ProductName}
+		for _, a := range item.Allergens {
+			lines[i].Allergens = append(lines[i].Allergens, product.Allergen(a).Label())
+		}
+	}
+
+	var fields []TicketField
+	for _, field := range o.CustomFields {
+		if field.PrintOnTicket {
+			fields = append(fields, TicketField{Label: field.Label, Value: field.Value})
+		}
+	}
+
+	ticket := OrderTicket{
+		Number:        orderNumber(o.ID),
+		StandName:     stand.Name,
+		PaidAt:        paidAt,
+		Lines:         lines,
+		Fields:        fields,
+		Notes:         o.Notes,
+		PaymentMethod: strings.ToUpper(o.PaymentMethod),
+		Reprint:       o.ReplacesID != nil,
+	}
+	if stand.CurrencyName != "" && stand.ExchangeRate > 0 {
+		ticket.Total = formatAmount(float64(o.TotalAmount)*stand.ExchangeRate, stand.CurrencyName)
+		if o.RoundUp != nil {
+			ticket.RoundUp = formatAmount(float64(o.RoundUp.Amount)*stand.ExchangeRate, stand.CurrencyName)
+			ticket.Charity = o.RoundUp.Charity
+		}
+	}
+	return ticket
+}
+
+// orderNumber is the short number called out at the counter, the start of the order ID
+func orderNumber(id uuid.UUID) string {
+	return "#" + strings.ToUpper(id.String()[:6])
+}
+
+func formatAmount(tokens float64, currencyName string) string {
+	if tokens == float64(int64(tokens)) {
+		return fmt.Sprintf("%.0f %s", tokens, currencyName)
+	}
+	return fmt.Sprintf("%.2f %s", tokens, currencyName)
+}
+
+func ackError(req AckRequest) string {
+	if req.Error == "" {
+		return "print agent reported a failure"
+	}
+	return req.Error
+}
+
+// validatePrinter checks the printer settings and returns the normalized address
+func validatePrinter(connection Connection, address string, width PaperWidth) (string, error) {
+	if !connection.IsValid() {
+		return "", ErrInvalidConnection
+	}
+	if !width.IsValid() {
+		return "", ErrInvalidPaperWidth
+	}
+
+	address = strings.TrimSpace(address)
+	if connection != ConnectionNetwork {
+		return address, nil
+	}
+	if address == "" {
+		return "", ErrInvalidAddress
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, DefaultNetworkPort
+	}
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return "", ErrInvalidAddress
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func printerChannel(printerID uuid.UUID) string {
+	return "printing:printer:" + printerID.String()
+}
+
+func generateAgentToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate agent token: %w", err)
+	}
+	return AgentTokenPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashAgentToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,435 @@
+package printing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+
+func newTestService(repo Repository) *Service {
+	service := NewService(repo, nil)
+	service.now = func() time.Time { return testNow }
+	return service
+}
+
+func testStand() *StandInfo {
+	return &StandInfo{
+		ID:           uuid.New(),
+		FestivalID:   uuid.New(),
+		Name:         "Bar Central",
+		Timezone:     "Europe/Brussels",
+		CurrencyName: "Jetons",
+		ExchangeRate: 0.10,
+	}
+}
+
+func testPrinter(stand *StandInfo, name string) *Printer {
+	return &Printer{
+		ID:         uuid.New(),
+		FestivalID: stand.FestivalID,
+		StandID:    stand.ID,
+		Name:       name,
+		Connection: ConnectionNetwork,
+		Address:    "192.168.1.50:9100",
+		PaperWidth: PaperWidth80,
+		Copies:     1,
+		Enabled:    true,
+	}
+}
+
+func paidOrder(stand *StandInfo) *order.Order {
+	return &order.Order{
+		ID:         uuid.New(),
+		FestivalID: stand.FestivalID,
+		StandID:    stand.ID,
+		Items: order.OrderItems{
+			{ProductName: "Bière blonde", Quantity: 2, UnitPrice: 350, TotalPrice: 700},
+			{ProductName: "Frites", Quantity: 1, UnitPrice: 400, TotalPrice: 400, Allergens: []string{"MUSTARD", "SOYBEANS"}},
+		},
+		TotalAmount:   1100,
+		Status:        order.OrderStatusPaid,
+		PaymentMethod: order.PaymentMethodWallet,
+		Notes:         "No mayo",
+		CustomFields: order.CustomFieldValues{
+			{Key: "table", Label: "Table", Value: "12", PrintOnTicket: true},
+			{Key: "source", Label: "Source", Value: "flyer"},
+		},
+		UpdatedAt: time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC),
+	}
+}
+
+// TestCreatePrinter tests that printers are validated and get a hashed agent token
+func TestCreatePrinter(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	stand := testStand()
+	ctx := context.Background()
+
+	var stored Printer
+	mockRepo.On("GetStandInfo", ctx, stand.ID).Return(stand, nil)
+	mockRepo.On("CreatePrinter", ctx, mock.AnythingOfType("*printing.Printer")).
+		Run(func(args mock.Arguments) { stored = *args.Get(1).(*Printer) }).
+		Return(nil).Once()
+
+	printer, err := service.CreatePrinter(ctx, stand.FestivalID, CreatePrinterRequest{
+		StandID:    stand.ID,
+		Name:       "Kitchen",
+		Connection: ConnectionNetwork,
+		Address:    "192.168.1.50",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.50:9100", printer.Address)
+	assert.Equal(t, PaperWidth80, printer.PaperWidth)
+	assert.Equal(t, 1, printer.Copies)
+	assert.True(t, printer.Enabled)
+	assert.Contains(t, printer.AgentToken, AgentTokenPrefix)
+	assert.Equal(t, hashAgentToken(printer.AgentToken), stored.TokenHash)
+	assert.NotEqual(t, printer.AgentToken, stored.TokenHash)
+
+	mockRepo.On("GetPrinterByTokenHash", ctx, stored.TokenHash).Return(&stored, nil).Once()
+	agent, err := service.AuthenticateAgent(ctx, printer.AgentToken)
+	require.NoError(t, err)
+	assert.Equal(t, printer.ID, agent.ID)
+
+	_, err = service.AuthenticateAgent(ctx, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidAgentToken)
+
+	_, err = service.CreatePrinter(ctx, stand.FestivalID, CreatePrinterRequest{
+		StandID:    stand.ID,
+		Name:       "No address",
+		Connection: ConnectionNetwork,
+	})
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+
+	_, err = service.CreatePrinter(ctx, uuid.New(), CreatePrinterRequest{
+		StandID:    stand.ID,
+		Name:       "Other festival",
+		Connection: ConnectionUSB,
+	})
+	assert.ErrorIs(t, err, ErrStandNotFound)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "CreatePrinter", 1)
+}
+
+// TestRotateAgentToken tests that a rotated token replaces the hash of the previous one
+func TestRotateAgentToken(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	stand := testStand()
+	printer := testPrinter(stand, "Kitchen")
+	printer.TokenHash = hashAgentToken(AgentTokenPrefix + "previous")
+
+	mockRepo.On("GetPrinter", mock.Anything, stand.FestivalID, printer.ID).Return(printer, nil)
+	mockRepo.On("UpdatePrinter", mock.Anything, printer).Return(nil).Once()
+
+	rotated, err := service.RotateAgentToken(context.Background(), stand.FestivalID, printer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hashAgentToken(rotated.AgentToken), printer.TokenHash)
+	assert.Equal(t, rotated.AgentToken[:len(AgentTokenPrefix)+6], printer.TokenPrefix)
+
+	mockRepo.On("GetPrinter", mock.Anything, stand.FestivalID, mock.Anything).Return(nil, nil)
+	_, err = service.RotateAgentToken(context.Background(), stand.FestivalID, uuid.New())
+	assert.ErrorIs(t, err, ErrPrinterNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPrintOrder tests that paid orders are queued on the enabled printers of their stand
+func TestPrintOrder(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	stand := testStand()
+	kitchen := testPrinter(stand, "Kitchen")
+
+	var jobs []PrintJob
+	mockRepo.On("ListEnabledPrinters", mock.Anything, stand.ID).Return([]Printer{*kitchen}, nil)
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	mockRepo.On("CreateJobs", mock.Anything, mock.AnythingOfType("[]printing.PrintJob")).
+		Run(func(args mock.Arguments) { jobs = args.Get(1).([]PrintJob) }).
+		Return(nil).Once()
+
+	o := paidOrder(stand)
+	require.NoError(t, service.PrintOrder(context.Background(), o))
+
+	require.Len(t, jobs, 1)
+	job := jobs[0]
+	assert.Equal(t, kitchen.ID, job.PrinterID)
+	assert.Equal(t, &o.ID, job.OrderID)
+	assert.Equal(t, JobStatusPending, job.Status)
+	assert.Equal(t, DefaultMaxAttempts, job.MaxAttempts)
+	assert.Equal(t, testNow, job.NextAttemptAt)
+	assert.True(t, bytes.HasPrefix(job.Payload, escInit))
+	assert.Contains(t, string(job.Payload), "Bar Central")
+	assert.Contains(t, string(job.Payload), "2 x Bi\xe8re blonde", "CP1252 text")
+	assert.Contains(t, string(job.Payload), "20:00", "festival time")
+	assert.Contains(t, string(job.Payload), "110 Jetons")
+	assert.Contains(t, string(job.Payload), "Table: 12")
+	assert.Contains(t, string(job.Payload), "    Allergens: Mustard, Soy", "under the item")
+	assert.NotContains(t, string(job.Payload), "flyer", "field not printed")
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPrintOrder_NoPrinter tests that stands without an enabled printer queue nothing
+func TestPrintOrder_NoPrinter(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	stand := testStand()
+
+	mockRepo.On("ListEnabledPrinters", mock.Anything, stand.ID).Return([]Printer{}, nil)
+
+	require.NoError(t, service.PrintOrder(context.Background(), paidOrder(stand)))
+	mockRepo.AssertNotCalled(t, "CreateJobs", mock.Anything, mock.Anything)
+}
+
+// TestPrintOrderRoundUp tests that the round-up donation of a wallet payment is printed
+func TestPrintOrderRoundUp(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	stand := testStand()
+
+	var jobs []PrintJob
+	mockRepo.On("ListEnabledPrinters", mock.Anything, stand.ID).Return([]Printer{*testPrinter(stand, "Kitchen")}, nil)
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	mockRepo.On("CreateJobs", mock.Anything, mock.AnythingOfType("[]printing.PrintJob")).
+		Run(func(args mock.Arguments) { jobs = args.Get(1).([]PrintJob) }).
+		Return(nil).Once()
+
+	o := paidOrder(stand)
+	o.RoundUp = &wallet.RoundUp{TransactionID: uuid.New(), Amount: 50, Charity: "Red Cross"}
+	require.NoError(t, service.PrintOrder(context.Background(), o))
+
+	require.Len(t, jobs, 1)
+	assert.Contains(t, string(jobs[0].Payload), "5 Jetons")
+	assert.Contains(t, string(jobs[0].Payload), "Donated to Red Cross")
+}
+
+// TestPollJobs tests that the due jobs claimed for a printer are handed to its agent
+func TestPollJobs(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	stand := testStand()
+	printer := testPrinter(stand, "Kitchen")
+	ctx := context.Background()
+
+	var queued []PrintJob
+	mockRepo.On("GetPrinter", ctx, stand.FestivalID, printer.ID).Return(printer, nil)
+	mockRepo.On("CreateJobs", ctx, mock.MatchedBy(func(jobs []PrintJob) bool {
+		return len(jobs) == 1 && jobs[0].Kind == JobKindTest && jobs[0].PrinterID == printer.ID
+	})).Run(func(args mock.Arguments) { queued = args.Get(1).([]PrintJob) }).Return(nil).Once()
+
+	job, err := service.PrintTestPage(ctx, stand.FestivalID, printer.ID)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, job.ID, queued[0].ID)
+
+	lease := testNow.Add(LeaseDuration)
+	leased := *job
+	leased.Status = JobStatusPrinting
+	leased.Attempts = 1
+	leased.LeaseExpiresAt = &lease
+	mockRepo.On("TouchPrinter", ctx, printer.ID, testNow).Return(nil)
+	mockRepo.On("ClaimJobs", ctx, printer.ID, testNow, MaxJobsPerPoll).Return([]PrintJob{leased}, nil).Once()
+
+	jobs, err := service.PollJobs(ctx, printer, 0)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+	assert.Equal(t, 1, jobs[0].Attempt)
+	assert.Equal(t, "192.168.1.50:9100", jobs[0].Address)
+	assert.Equal(t, lease, jobs[0].AckBefore)
+
+	mockRepo.On("ClaimJobs", ctx, printer.ID, testNow, MaxJobsPerPoll).Return([]PrintJob{}, nil).Once()
+	jobs, err = service.PollJobs(ctx, printer, 0)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	t.Run("disabled printer", func(t *testing.T) {
+		disabled := *printer
+		disabled.Enabled = false
+		jobs, err := service.PollJobs(ctx, &disabled, 0)
+		require.NoError(t, err)
+		assert.Empty(t, jobs)
+	})
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "TouchPrinter", 3)
+	mockRepo.AssertNumberOfCalls(t, "ClaimJobs", 2)
+}
+
+// TestAckJob tests the outcome of acknowledged jobs: printed, retried after the
+// backoff, or failed once out of attempts
+func TestAckJob(t *testing.T) {
+	stand := testStand()
+	printer := testPrinter(stand, "Kitchen")
+	lease := testNow.Add(-time.Second)
+
+	tests := []struct {
+		name       string
+		status     JobStatus
+		attempts   int
+		req        AckRequest
+		wantErr    error
+		wantStatus JobStatus
+		wantUpdate bool
+		validate   func(*testing.T, *PrintJob)
+	}{
+		{
+			name:       "printed",
+			status:     JobStatusPrinting,
+			attempts:   1,
+			req:        AckRequest{Success: true},
+			wantStatus: JobStatusPrinted,
+			wantUpdate: true,
+			validate: func(t *testing.T, job *PrintJob) {
+				assert.Equal(t, &testNow, job.PrintedAt)
+				assert.Nil(t, job.LeaseExpiresAt)
+			},
+		},
+		{
+			name:       "retried after the backoff",
+			status:     JobStatusPrinting,
+			attempts:   1,
+			req:        AckRequest{Error: "paper out"},
+			wantStatus: JobStatusPending,
+			wantUpdate: true,
+			validate: func(t *testing.T, job *PrintJob) {
+				assert.Equal(t, "paper out", job.LastError)
+				assert.Equal(t, testNow.Add(RetryBaseDelay), job.NextAttemptAt)
+			},
+		},
+		{
+			name:       "failed on the last attempt",
+			status:     JobStatusPrinting,
+			attempts:   DefaultMaxAttempts,
+			req:        AckRequest{Error: "paper out"},
+			wantStatus: JobStatusFailed,
+			wantUpdate: true,
+		},
+		{
+			name:       "printed twice",
+			status:     JobStatusPrinted,
+			attempts:   1,
+			req:        AckRequest{Success: true},
+			wantStatus: JobStatusPrinted,
+		},
+		{
+			name:     "not leased",
+			status:   JobStatusFailed,
+			attempts: DefaultMaxAttempts,
+			req:      AckRequest{Success: true},
+			wantErr:  ErrJobNotLeased,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRepository()
+			service := newTestService(mockRepo)
+
+			job := &PrintJob{
+				ID:             uuid.New(),
+				PrinterID:      printer.ID,
+				Status:         tt.status,
+				Attempts:       tt.attempts,
+				MaxAttempts:    DefaultMaxAttempts,
+				LeaseExpiresAt: &lease,
+			}
+			mockRepo.On("GetPrinterJob", mock.Anything, printer.ID, job.ID).Return(job, nil)
+			mockRepo.On("UpdateJob", mock.Anything, job).Return(nil)
+
+			acked, err := service.AckJob(context.Background(), printer, job.ID, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "UpdateJob", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, acked.Status)
+			if tt.wantUpdate {
+				mockRepo.AssertCalled(t, "UpdateJob", mock.Anything, job)
+			} else {
+				mockRepo.AssertNotCalled(t, "UpdateJob", mock.Anything, mock.Anything)
+			}
+			if tt.validate != nil {
+				tt.validate(t, acked)
+			}
+		})
+	}
+
+	t.Run("job of another printer", func(t *testing.T) {
+		mockRepo := NewMockRepository()
+		service := newTestService(mockRepo)
+		mockRepo.On("GetPrinterJob", mock.Anything, printer.ID, mock.Anything).Return(nil, nil)
+
+		_, err := service.AckJob(context.Background(), printer, uuid.New(), AckRequest{Success: true})
+		assert.ErrorIs(t, err, ErrJobNotFound)
+	})
+}
+
+// TestRetryJob tests that failed jobs are queued again with a fresh set of attempts
+func TestRetryJob(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	lease := testNow.Add(-time.Minute)
+
+	failed := &PrintJob{
+		ID:             uuid.New(),
+		FestivalID:     festivalID,
+		Status:         JobStatusFailed,
+		Attempts:       DefaultMaxAttempts,
+		MaxAttempts:    DefaultMaxAttempts,
+		LeaseExpiresAt: &lease,
+	}
+	mockRepo.On("GetJob", mock.Anything, festivalID, failed.ID).Return(failed, nil)
+	mockRepo.On("UpdateJob", mock.Anything, failed).Return(nil).Once()
+
+	retried, err := service.RetryJob(context.Background(), festivalID, failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusPending, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+	assert.Equal(t, testNow, retried.NextAttemptAt)
+	assert.Nil(t, retried.LeaseExpiresAt)
+
+	printed := &PrintJob{ID: uuid.New(), FestivalID: festivalID, Status: JobStatusPrinted}
+	mockRepo.On("GetJob", mock.Anything, festivalID, printed.ID).Return(printed, nil)
+	_, err = service.RetryJob(context.Background(), festivalID, printed.ID)
+	assert.ErrorIs(t, err, ErrJobNotRetryable)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestHandleSweepJobs tests that jobs whose last lease expired are failed
+func TestHandleSweepJobs(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+
+	mockRepo.On("FailExpiredJobs", mock.Anything, testNow).Return(int64(2), nil).Once()
+	require.NoError(t, service.HandleSweepJobs(context.Background(), nil))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 10*time.Second, retryDelay(1))
+	assert.Equal(t, 20*time.Second, retryDelay(2))
+	assert.Equal(t, 80*time.Second, retryDelay(4))
+	assert.Equal(t, RetryMaxDelay, retryDelay(10))
+}
+
+func TestWrapText(t *testing.T) {
+	assert.Equal(t, []string{"Grande bière", "pression"}, wrapText("Grande bière pression", 12))
+	assert.Equal(t, []string{"abcdef", "ghij"}, wrapText("abcdefghij", 6))
+	assert.Equal(t, []string{""}, wrapText("", 10))
+}
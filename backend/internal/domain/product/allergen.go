@@ -0,0 +1,344 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidDeclaration is returned for unknown allergens or dietary tags, dietary tags
+// contradicting the allergens, and inconsistent nutrition values
+var ErrInvalidDeclaration = errors.New("invalid allergen or dietary declaration")
+
+// Allergen is one of the 14 allergens food businesses in the EU must declare
+// (Regulation (EU) No 1169/2011, Annex II)
+type Allergen string
+
+const (
+	AllergenGluten      Allergen = "GLUTEN" // Cereals containing gluten
+	AllergenCrustaceans Allergen = "CRUSTACEANS"
+	AllergenEggs        Allergen = "EGGS"
+	AllergenFish        Allergen = "FISH"
+	AllergenPeanuts     Allergen = "PEANUTS"
+	AllergenSoybeans    Allergen = "SOYBEANS"
+	AllergenMilk        Allergen = "MILK" // Lactose included
+	AllergenNuts        Allergen = "NUTS" // Tree nuts
+	AllergenCelery      Allergen = "CELERY"
+	AllergenMustard     Allergen = "MUSTARD"
+	AllergenSesame      Allergen = "SESAME"
+	AllergenSulphites   Allergen = "SULPHITES" // Above 10 mg/kg or 10 mg/l
+	AllergenLupin       Allergen = "LUPIN"
+	AllergenMolluscs    Allergen = "MOLLUSCS"
+)
+
+// Allergens lists the allergens in the order of Annex II, which declarations follow
+var Allergens = []Allergen{
+	AllergenGluten, AllergenCrustaceans, AllergenEggs, AllergenFish, AllergenPeanuts, AllergenSoybeans, AllergenMilk,
+	AllergenNuts, AllergenCelery, AllergenMustard, AllergenSesame, AllergenSulphites, AllergenLupin, AllergenMolluscs,
+}
+
+// IsValid checks if the allergen is one of the 14
+func (a Allergen) IsValid() bool {
+	for _, allergen := range Allergens {
+		if a == allergen {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the name of the allergen printed on the order tickets
+func (a Allergen) Label() string {
+	if a == AllergenSoybeans {
+		return "Soy"
+	}
+	return strings.ToUpper(string(a[:1])) + strings.ToLower(string(a[1:]))
+}
+
+// DietaryTag is a diet a product suits
+type DietaryTag string
+
+const (
+	DietaryVegan      DietaryTag = "VEGAN"
+	DietaryVegetarian DietaryTag = "VEGETARIAN"
+	DietaryHalal      DietaryTag = "HALAL"
+	DietaryGlutenFree DietaryTag = "GLUTEN_FREE"
+)
+
+// DietaryTags lists the dietary tags
+var DietaryTags = []DietaryTag{DietaryVegan, DietaryVegetarian, DietaryHalal, DietaryGlutenFree}
+
+// IsValid checks if the dietary tag is known
+func (t DietaryTag) IsValid() bool {
+	for _, tag := range DietaryTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// dietaryConflicts are the allergens a product with a dietary tag cannot contain
+var dietaryConflicts = map[DietaryTag][]Allergen{
+	DietaryVegan:      {AllergenCrustaceans, AllergenEggs, AllergenFish, AllergenMilk, AllergenMolluscs},
+	DietaryVegetarian: {AllergenCrustaceans, AllergenFish, AllergenMolluscs},
+	DietaryGlutenFree: {AllergenGluten},
+}
+
+// NutritionBasis is the quantity the nutrition values are given for
+type NutritionBasis string
+
+const (
+	NutritionPer100g  NutritionBasis = "100g"
+	NutritionPer100ml NutritionBasis = "100ml"
+)
+
+// Nutrition is the nutrition declaration of a product, with the values of the EU
+// declaration per 100 g or 100 ml; masses in grams
+type Nutrition struct {
+	Basis         NutritionBasis `json:"basis" binding:"required,oneof=100g 100ml"`
+	EnergyKcal    float64        `json:"energyKcal" binding:"min=0"`
+	Fat           float64        `json:"fat" binding:"min=0"`
+	SaturatedFat  float64        `json:"saturatedFat" binding:"min=0"`
+	Carbohydrates float64        `json:"carbohydrates" binding:"min=0"`
+	Sugars        float64        `json:"sugars" binding:"min=0"`
+	Protein       float64        `json:"protein" binding:"min=0"`
+	Salt          float64        `json:"salt" binding:"min=0"`
+}
+
+// validate checks the values of a nutrition declaration add up
+func (n *Nutrition) validate() error {
+	if n.Basis != NutritionPer100g && n.Basis != NutritionPer100ml {
+		return fmt.Errorf("%w: nutrition basis must be 100g or 100ml", ErrInvalidDeclaration)
+	}
+	for _, v := range []float64{n.EnergyKcal, n.Fat, n.SaturatedFat, n.Carbohydrates, n.Sugars, n.Protein, n.Salt} {
+		if v < 0 {
+			return fmt.Errorf("%w: nutrition values must not be negative", ErrInvalidDeclaration)
+		}
+	}
+	if n.SaturatedFat > n.Fat {
+		return fmt.Errorf("%w: saturated fat exceeds fat", ErrInvalidDeclaration)
+	}
+	if n.Sugars > n.Carbohydrates {
+		return fmt.Errorf("%w: sugars exceed carbohydrates", ErrInvalidDeclaration)
+	}
+	if n.Fat+n.Carbohydrates+n.Protein+n.Salt > 100 {
+		return fmt.Errorf("%w: nutrients exceed 100 g per %s", ErrInvalidDeclaration, n.Basis)
+	}
+	return nil
+}
+
+// DeclarationCategories are the categories of food and drinks, whose allergens must be declared
+var DeclarationCategories = []ProductCategory{
+	ProductCategoryBeer, ProductCategoryCocktail, ProductCategorySoft, ProductCategoryFood, ProductCategorySnack,
+}
+
+// RequiresDeclaration tells whether the allergens of the products of a category must be declared
+func (c ProductCategory) RequiresDeclaration() bool {
+	for _, category := range DeclarationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAllergens checks the allergens and sorts them in the order of Annex II,
+// without duplicates
+func normalizeAllergens(allergens []Allergen) ([]Allergen, error) {
+	declared := make(map[Allergen]bool, len(allergens))
+	for _, a := range allergens {
+		a = Allergen(strings.ToUpper(strings.TrimSpace(string(a))))
+		if !a.IsValid() {
+			return nil, fmt.Errorf("%w: unknown allergen %q", ErrInvalidDeclaration, a)
+		}
+		declared[a] = true
+	}
+
+	normalized := make([]Allergen, 0, len(declared))
+	for _, a := range Allergens {
+		if declared[a] {
+			normalized = append(normalized, a)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeDietaryTags checks the dietary tags and sorts them, without duplicates
+func normalizeDietaryTags(tags []DietaryTag) ([]DietaryTag, error) {
+	declared := make(map[DietaryTag]bool, len(tags))
+	for _, t := range tags {
+		t = DietaryTag(strings.ToUpper(strings.TrimSpace(string(t))))
+		if !t.IsValid() {
+			return nil, fmt.Errorf("%w: unknown dietary tag %q", ErrInvalidDeclaration, t)
+		}
+		declared[t] = true
+	}
+
+	normalized := make([]DietaryTag, 0, len(declared))
+	for _, t := range DietaryTags {
+		if declared[t] {
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
+
+// applyDeclaration sets the allergens, dietary tags and nutrition of a product, the nil
+// ones left unchanged, and checks they are consistent. Dietary tags are claims on the
+// allergens, so they can only be set on a product whose allergens are declared.
+func applyDeclaration(product *Product, allergens []Allergen, tags []DietaryTag, nutrition *Nutrition, now time.Time) error {
+	if allergens != nil {
+		normalized, err := normalizeAllergens(allergens)
+		if err != nil {
+			return err
+		}
+		product.Allergens = normalized
+		product.AllergensDeclaredAt = &now
+	}
+	if tags != nil {
+		normalized, err := normalizeDietaryTags(tags)
+		if err != nil {
+			return err
+		}
+		product.DietaryTags = normalized
+	}
+	if nutrition != nil {
+		if err := nutrition.validate(); err != nil {
+			return err
+		}
+		product.Nutrition = nutrition
+	}
+
+	if product.Allergens == nil {
+		product.Allergens = []Allergen{}
+	}
+	if product.DietaryTags == nil {
+		product.DietaryTags = []DietaryTag{}
+	}
+
+	if len(product.DietaryTags) > 0 && product.AllergensDeclaredAt == nil {
+		return fmt.Errorf("%w: declare the allergens before the dietary tags", ErrInvalidDeclaration)
+	}
+	for _, tag := range product.DietaryTags {
+		for _, conflict := range dietaryConflicts[tag] {
+			if product.Contains(conflict) {
+				return fmt.Errorf("%w: a %s product cannot contain %s", ErrInvalidDeclaration, tag, conflict)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains tells whether the product is declared to contain an allergen
+func (p *Product) Contains(allergen Allergen) bool {
+	for _, a := range p.Allergens {
+		if a == allergen {
+			return true
+		}
+	}
+	return false
+}
+
+// MenuFilter selects the products of a menu suiting an attendee. Empty fields do not
+// restrict the selection.
+type MenuFilter struct {
+	FreeOf  []Allergen   // Products declared without any of these allergens
+	Dietary []DietaryTag // Products with all these tags
+}
+
+// ParseMenuFilter parses the comma-separated allergenFree and dietary query parameters
+func ParseMenuFilter(freeOf, dietary string) (MenuFilter, error) {
+	var filter MenuFilter
+	var err error
+	if freeOf != "" {
+		var allergens []Allergen
+		for _, a := range strings.Split(freeOf, ",") {
+			allergens = append(allergens, Allergen(a))
+		}
+		if filter.FreeOf, err = normalizeAllergens(allergens); err != nil {
+			return MenuFilter{}, err
+		}
+	}
+	if dietary != "" {
+		var tags []DietaryTag
+		for _, t := range strings.Split(dietary, ",") {
+			tags = append(tags, DietaryTag(t))
+		}
+		if filter.Dietary, err = normalizeDietaryTags(tags); err != nil {
+			return MenuFilter{}, err
+		}
+	}
+	return filter, nil
+}
+
+// IsZero tells whether the filter selects every product
+func (f MenuFilter) IsZero() bool {
+	return len(f.FreeOf) == 0 && len(f.Dietary) == 0
+}
+
+// Matches tells whether a product passes the filter. A product whose allergens are not
+// declared is never free of any.
+func (f MenuFilter) Matches(p *Product) bool {
+	if len(f.FreeOf) > 0 {
+		if p.AllergensDeclaredAt == nil {
+			return false
+		}
+		for _, a := range f.FreeOf {
+			if p.Contains(a) {
+				return false
+			}
+		}
+	}
+	for _, tag := range f.Dietary {
+		found := false
+		for _, t := range p.DietaryTags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterProducts keeps the products passing a filter
+func filterProducts(products []Product, filter MenuFilter) []Product {
+	if filter.IsZero() {
+		return products
+	}
+	kept := make([]Product, 0, len(products))
+	for i := range products {
+		if filter.Matches(&products[i]) {
+			kept = append(kept, products[i])
+		}
+	}
+	return kept
+}
+
+// UndeclaredProduct is a food or drink product whose allergens are not declared
+type UndeclaredProduct struct {
+	ProductID uuid.UUID       `json:"productId"`
+	Name      string          `json:"name"`
+	StandID   uuid.UUID       `json:"standId"`
+	StandName string          `json:"standName"`
+	Category  ProductCategory `json:"category"`
+	Status    ProductStatus   `json:"status"`
+}
+
+// AllergenReport lists the food and drink products of a festival missing their
+// allergen declaration
+type AllergenReport struct {
+	FestivalID  uuid.UUID           `json:"festivalId"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Required    int64               `json:"required"` // Food and drink products
+	Declared    int64               `json:"declared"`
+	Compliant   bool                `json:"compliant"`
+	Missing     []UndeclaredProduct `json:"missing"` // By stand, active products first
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/mimi6060/festivals/backend/internal/pkg/response"
 )
 
@@ -47,6 +48,12 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("/stands/:id/products", h.ListByStand)
 }
 
+// RegisterComplianceRoutes registers the allergen compliance report, which should be
+// restricted to organizers unlike the product routes
+func (h *Handler) RegisterComplianceRoutes(r *gin.RouterGroup) {
+	r.GET("/products/allergen-report", h.AllergenReport)
+}
+
 // Create creates a new product
 // @Summary Create a new product
 // @Description Create a new product for a stand
@@ -64,12 +71,24 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 func (h *Handler) Create(c *gin.Context) {
 	var req CreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
 	product, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
+		if err == errors.ErrNotFound {
+			response.NotFound(c, "Stand not found")
+			return
+		}
+		if i18n.IsInvalidText(err) {
+			response.BadRequest(c, "INVALID_TRANSLATIONS", err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrInvalidDeclaration) {
+			response.BadRequest(c, "INVALID_DECLARATION", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -94,12 +113,24 @@ func (h *Handler) Create(c *gin.Context) {
 func (h *Handler) CreateBulk(c *gin.Context) {
 	var req BulkCreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
 	products, err := h.service.CreateBulk(c.Request.Context(), req)
 	if err != nil {
+		if err == errors.ErrNotFound {
+			response.NotFound(c, "Stand not found")
+			return
+		}
+		if i18n.IsInvalidText(err) {
+			response.BadRequest(c, "INVALID_TRANSLATIONS", err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrInvalidDeclaration) {
+			response.BadRequest(c, "INVALID_DECLARATION", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -121,8 +152,12 @@ func (h *Handler) CreateBulk(c *gin.Context) {
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(50)
 // @Param category query string false "Filter by category" Enums(food, drinks, merchandise, other)
+// @Param categoryId query string false "Filter by festival category, including subcategories" format(uuid)
+// @Param allergenFree query string false "Comma-separated allergens the products must be declared free of" example(GLUTEN,MILK)
+// @Param dietary query string false "Comma-separated dietary tags the products must all have" example(VEGAN)
+// @Param lang query string false "Languages to localize names and descriptions in, before Accept-Language" example(fr-BE)
 // @Success 200 {object} response.Response{data=[]ProductResponse,meta=response.Meta} "Product list"
-// @Failure 400 {object} response.ErrorResponse "Invalid stand ID"
+// @Failure 400 {object} response.ErrorResponse "Invalid stand ID or filter"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
@@ -140,10 +175,20 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	filter, ok := menuFilter(c)
+	if !ok {
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
 	category := c.Query("category")
 
+	if c.Query("categoryId") != "" {
+		h.listByCategoryID(c, standID, filter)
+		return
+	}
+
 	if category != "" {
 		products, err := h.service.ListByCategory(c.Request.Context(), standID, ProductCategory(category))
 		if err != nil {
@@ -151,25 +196,19 @@ func (h *Handler) List(c *gin.Context) {
 			return
 		}
 
-		items := make([]ProductResponse, len(products))
-		for i, p := range products {
-			items[i] = p.ToResponse(h.exchangeRate, h.currencyName)
-		}
+		items := h.toLocalizedResponses(c, filterProducts(products, filter))
 
 		response.OK(c, items)
 		return
 	}
 
-	products, total, err := h.service.List(c.Request.Context(), standID, page, perPage)
+	products, total, err := h.service.ListMenu(c.Request.Context(), standID, filter, page, perPage)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
 	}
 
-	items := make([]ProductResponse, len(products))
-	for i, p := range products {
-		items[i] = p.ToResponse(h.exchangeRate, h.currencyName)
-	}
+	items := h.toLocalizedResponses(c, products)
 
 	response.OKWithMeta(c, items, &response.Meta{
 		Total:   int(total),
@@ -186,8 +225,12 @@ func (h *Handler) List(c *gin.Context) {
 // @Param standId path string true "Stand ID" format(uuid)
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(50)
+// @Param categoryId query string false "Filter by festival category, including subcategories" format(uuid)
+// @Param allergenFree query string false "Comma-separated allergens the products must be declared free of" example(GLUTEN,MILK)
+// @Param dietary query string false "Comma-separated dietary tags the products must all have" example(VEGAN)
+// @Param lang query string false "Languages to localize names and descriptions in, before Accept-Language" example(fr-BE)
 // @Success 200 {object} response.Response{data=[]ProductResponse,meta=response.Meta} "Product list"
-// @Failure 400 {object} response.ErrorResponse "Invalid stand ID"
+// @Failure 400 {object} response.ErrorResponse "Invalid stand ID or filter"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
@@ -199,19 +242,26 @@ func (h *Handler) ListByStand(c *gin.Context) {
 		return
 	}
 
+	filter, ok := menuFilter(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("categoryId") != "" {
+		h.listByCategoryID(c, standID, filter)
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
 
-	products, total, err := h.service.List(c.Request.Context(), standID, page, perPage)
+	products, total, err := h.service.ListMenu(c.Request.Context(), standID, filter, page, perPage)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
 	}
 
-	items := make([]ProductResponse, len(products))
-	for i, p := range products {
-		items[i] = p.ToResponse(h.exchangeRate, h.currencyName)
-	}
+	items := h.toLocalizedResponses(c, products)
 
 	response.OKWithMeta(c, items, &response.Meta{
 		Total:   int(total),
@@ -220,12 +270,32 @@ func (h *Handler) ListByStand(c *gin.Context) {
 	})
 }
 
+// listByCategoryID writes the active products of a stand in the requested festival category
+func (h *Handler) listByCategoryID(c *gin.Context, standID uuid.UUID, filter MenuFilter) {
+	categoryID, err := uuid.Parse(c.Query("categoryId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid category ID", nil)
+		return
+	}
+
+	products, err := h.service.ListByCategoryID(c.Request.Context(), standID, categoryID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	items := h.toLocalizedResponses(c, filterProducts(products, filter))
+
+	response.OK(c, items)
+}
+
 // GetByID gets a product by ID
 // @Summary Get product by ID
 // @Description Get detailed information about a specific product
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID" format(uuid)
+// @Param lang query string false "Languages to localize the name and description in, before Accept-Language" example(fr-BE)
 // @Success 200 {object} response.Response{data=ProductResponse} "Product details"
 // @Failure 400 {object} response.ErrorResponse "Invalid product ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
@@ -250,7 +320,10 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
-	response.OK(c, product.ToResponse(h.exchangeRate, h.currencyName))
+	resp := product.ToResponse(h.exchangeRate, h.currencyName)
+	resp.Localize(i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language")))
+
+	response.OK(c, resp)
 }
 
 // Update updates a product
@@ -265,6 +338,7 @@ func (h *Handler) GetByID(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Failure 409 {object} response.ErrorResponse "Product recalled"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /festivals/{festivalId}/products/{id} [patch]
@@ -277,7 +351,7 @@ func (h *Handler) Update(c *gin.Context) {
 
 	var req UpdateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -287,6 +361,18 @@ func (h *Handler) Update(c *gin.Context) {
 			response.NotFound(c, "Product not found")
 			return
 		}
+		if err == ErrProductRecalled {
+			response.Conflict(c, "PRODUCT_RECALLED", "The product is recalled; lift the recall to sell it again")
+			return
+		}
+		if i18n.IsInvalidText(err) {
+			response.BadRequest(c, "INVALID_TRANSLATIONS", err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrInvalidDeclaration) {
+			response.BadRequest(c, "INVALID_DECLARATION", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -335,6 +421,7 @@ func (h *Handler) Delete(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid product ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Failure 409 {object} response.ErrorResponse "Product recalled"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /festivals/{festivalId}/products/{id}/activate [post]
@@ -351,6 +438,10 @@ func (h *Handler) Activate(c *gin.Context) {
 			response.NotFound(c, "Product not found")
 			return
 		}
+		if err == ErrProductRecalled {
+			response.Conflict(c, "PRODUCT_RECALLED", "The product is recalled; lift the recall to sell it again")
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -368,6 +459,7 @@ func (h *Handler) Activate(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid product ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Failure 409 {object} response.ErrorResponse "Product recalled"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /festivals/{festivalId}/products/{id}/deactivate [post]
@@ -384,6 +476,10 @@ func (h *Handler) Deactivate(c *gin.Context) {
 			response.NotFound(c, "Product not found")
 			return
 		}
+		if err == ErrProductRecalled {
+			response.Conflict(c, "PRODUCT_RECALLED", "The product is recalled; lift the recall to sell it again")
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -417,7 +513,7 @@ func (h *Handler) UpdateStock(c *gin.Context) {
 		Delta int `json:"delta" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -443,3 +539,54 @@ func (h *Handler) UpdateStock(c *gin.Context) {
 
 	response.OK(c, product.ToResponse(h.exchangeRate, h.currencyName))
 }
+
+// AllergenReport lists the food and drink products missing their allergen declaration
+// @Summary Allergen compliance report
+// @Description List the food and drink products of the festival whose allergens are not declared. Under the EU food information rules, the 14 major allergens of every food and drink sold must be available to attendees; an empty list declares that a product contains none.
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=AllergenReport} "Compliance report"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/allergen-report [get]
+func (h *Handler) AllergenReport(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	report, err := h.service.AllergenReport(c.Request.Context(), festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// menuFilter parses the allergenFree and dietary query parameters, writing a bad request
+// when they name unknown allergens or tags
+func menuFilter(c *gin.Context) (MenuFilter, bool) {
+	filter, err := ParseMenuFilter(c.Query("allergenFree"), c.Query("dietary"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FILTER", err.Error(), nil)
+		return MenuFilter{}, false
+	}
+	return filter, true
+}
+
+// toLocalizedResponses converts products for attendees, with their names and descriptions
+// in the languages asked by the lang query parameter or the Accept-Language header
+func (h *Handler) toLocalizedResponses(c *gin.Context, products []Product) []ProductResponse {
+	languages := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+	items := make([]ProductResponse, len(products))
+	for i, p := range products {
+		items[i] = p.ToResponse(h.exchangeRate, h.currencyName)
+		items[i].Localize(languages)
+	}
+	return items
+}
@@ -1,10 +1,12 @@
 package product
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 )
 
 // Product represents an item sold at a stand
@@ -13,14 +15,24 @@ type Product struct {
 	StandID     uuid.UUID      `json:"standId" gorm:"type:uuid;not null;index"`
 	Name        string         `json:"name" gorm:"not null"`
 	Description string         `json:"description"`
+	NameTranslations        i18n.Text `json:"nameTranslations,omitempty" gorm:"type:jsonb;default:'{}'"` // Keyed by language tag, including the festival's default locale
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations,omitempty" gorm:"type:jsonb;default:'{}'"`
 	Price       int64          `json:"price" gorm:"not null"` // Price in cents
+	CostPrice   *int64         `json:"costPrice,omitempty"`   // Unit cost of goods in cents, nil = unknown
 	Category    ProductCategory `json:"category" gorm:"not null"`
+	CategoryID  *uuid.UUID     `json:"categoryId,omitempty" gorm:"type:uuid;index"` // Festival-level category
+	TaxClass    string         `json:"taxClass,omitempty"`                         // Defaults to the category's tax class
 	ImageURL    string         `json:"imageUrl,omitempty"`
 	SKU         string         `json:"sku,omitempty" gorm:"index"` // Stock keeping unit
 	Stock       *int           `json:"stock,omitempty"`            // nil = unlimited
 	SortOrder   int            `json:"sortOrder" gorm:"default:0"`
 	Status      ProductStatus  `json:"status" gorm:"default:'ACTIVE'"`
 	Tags        []string       `json:"tags" gorm:"type:text[];serializer:json"`
+	Allergens   []Allergen     `json:"allergens" gorm:"type:jsonb;serializer:json"` // Annex II order; empty = contains none, when declared
+	AllergensDeclaredAt *time.Time `json:"allergensDeclaredAt,omitempty"`         // nil = allergens not declared
+	DietaryTags []DietaryTag   `json:"dietaryTags" gorm:"type:jsonb;serializer:json"`
+	Nutrition   *Nutrition     `json:"nutrition,omitempty" gorm:"type:jsonb;serializer:json"`
+	ImportID    *uuid.UUID     `json:"importId,omitempty" gorm:"column:legacy_import_id;type:uuid"` // Legacy import that brought the product from a previous provider
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 }
@@ -41,12 +53,17 @@ const (
 	ProductCategoryOther     ProductCategory = "OTHER"
 )
 
+// ErrProductRecalled is returned when changing the status of a recalled product, or
+// recalling a product outside of a recall
+var ErrProductRecalled = errors.New("product status is managed by its recall")
+
 type ProductStatus string
 
 const (
 	ProductStatusActive   ProductStatus = "ACTIVE"
 	ProductStatusInactive ProductStatus = "INACTIVE"
 	ProductStatusOutOfStock ProductStatus = "OUT_OF_STOCK"
+	ProductStatusRecalled ProductStatus = "RECALLED" // Disabled by a festival-wide recall until it is lifted
 )
 
 // CreateProductRequest represents the request to create a product
@@ -54,27 +71,43 @@ type CreateProductRequest struct {
 	StandID     uuid.UUID       `json:"standId" binding:"required"`
 	Name        string          `json:"name" binding:"required"`
 	Description string          `json:"description"`
+	NameTranslations        i18n.Text `json:"nameTranslations"` // Must include the festival's default locale, whose text becomes the name
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations"`
 	Price       int64           `json:"price" binding:"required,min=0"`
+	CostPrice   *int64          `json:"costPrice" binding:"omitempty,min=0"`
 	Category    ProductCategory `json:"category" binding:"required"`
+	CategoryID  *uuid.UUID      `json:"categoryId"`
+	TaxClass    string          `json:"taxClass"`
 	ImageURL    string          `json:"imageUrl"`
 	SKU         string          `json:"sku"`
 	Stock       *int            `json:"stock"`
 	SortOrder   int             `json:"sortOrder"`
 	Tags        []string        `json:"tags"`
+	Allergens   []Allergen      `json:"allergens"`   // nil = not declared, empty = contains none
+	DietaryTags []DietaryTag    `json:"dietaryTags"` // Requires the allergens
+	Nutrition   *Nutrition      `json:"nutrition"`
 }
 
 // UpdateProductRequest represents the request to update a product
 type UpdateProductRequest struct {
 	Name        *string          `json:"name,omitempty"`
 	Description *string          `json:"description,omitempty"`
+	NameTranslations        i18n.Text `json:"nameTranslations,omitempty"` // Replaces all translations; an empty object removes them
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations,omitempty"`
 	Price       *int64           `json:"price,omitempty"`
+	CostPrice   *int64           `json:"costPrice,omitempty" binding:"omitempty,min=0"`
 	Category    *ProductCategory `json:"category,omitempty"`
+	CategoryID  *uuid.UUID       `json:"categoryId,omitempty"`
+	TaxClass    *string          `json:"taxClass,omitempty"`
 	ImageURL    *string          `json:"imageUrl,omitempty"`
 	SKU         *string          `json:"sku,omitempty"`
 	Stock       *int             `json:"stock,omitempty"`
 	SortOrder   *int             `json:"sortOrder,omitempty"`
 	Status      *ProductStatus   `json:"status,omitempty"`
 	Tags        []string         `json:"tags,omitempty"`
+	Allergens   []Allergen       `json:"allergens,omitempty"`   // Replaces the declaration; an empty list declares none
+	DietaryTags []DietaryTag     `json:"dietaryTags,omitempty"` // Replaces the tags; an empty list removes them
+	Nutrition   *Nutrition       `json:"nutrition,omitempty"`
 }
 
 // BulkCreateProductRequest represents bulk product creation
@@ -89,15 +122,23 @@ type ProductResponse struct {
 	StandID      uuid.UUID       `json:"standId"`
 	Name         string          `json:"name"`
 	Description  string          `json:"description"`
+	NameTranslations        i18n.Text `json:"nameTranslations,omitempty"`
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations,omitempty"`
 	Price        int64           `json:"price"`
 	PriceDisplay string          `json:"priceDisplay"`
 	Category     ProductCategory `json:"category"`
+	CategoryID   *uuid.UUID      `json:"categoryId,omitempty"`
+	TaxClass     string          `json:"taxClass,omitempty"`
 	ImageURL     string          `json:"imageUrl,omitempty"`
 	SKU          string          `json:"sku,omitempty"`
 	Stock        *int            `json:"stock,omitempty"`
 	SortOrder    int             `json:"sortOrder"`
 	Status       ProductStatus   `json:"status"`
 	Tags         []string        `json:"tags"`
+	Allergens    []Allergen      `json:"allergens"`
+	AllergensDeclared bool       `json:"allergensDeclared"` // When false, allergens are unknown rather than none
+	DietaryTags  []DietaryTag    `json:"dietaryTags"`
+	Nutrition    *Nutrition      `json:"nutrition,omitempty"`
 	CreatedAt    string          `json:"createdAt"`
 	UpdatedAt    string          `json:"updatedAt"`
 }
@@ -112,20 +153,35 @@ func (p *Product) ToResponse(exchangeRate float64, currencyName string) ProductR
 		StandID:      p.StandID,
 		Name:         p.Name,
 		Description:  p.Description,
+		NameTranslations:        p.NameTranslations,
+		DescriptionTranslations: p.DescriptionTranslations,
 		Price:        p.Price,
 		PriceDisplay: priceDisplay,
 		Category:     p.Category,
+		CategoryID:   p.CategoryID,
+		TaxClass:     p.TaxClass,
 		ImageURL:     p.ImageURL,
 		SKU:          p.SKU,
 		Stock:        p.Stock,
 		SortOrder:    p.SortOrder,
 		Status:       p.Status,
 		Tags:         p.Tags,
+		Allergens:    p.Allergens,
+		AllergensDeclared: p.AllergensDeclaredAt != nil,
+		DietaryTags:  p.DietaryTags,
+		Nutrition:    p.Nutrition,
 		CreatedAt:    p.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:    p.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
+// Localize sets the name and description in the best of the languages asked, keeping
+// the text in the festival's default locale when there is no translation
+func (r *ProductResponse) Localize(languages []string) {
+	r.Name = r.NameTranslations.Localize(languages, r.Name)
+	r.Description = r.DescriptionTranslations.Localize(languages, r.Description)
+}
+
 func formatPrice(tokens float64, currencyName string) string {
 	if tokens == float64(int64(tokens)) {
 		return fmt.Sprintf("%.0f %s", tokens, currencyName)
@@ -0,0 +1,256 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Price list errors
+var (
+	ErrPriceListNotFound        = errors.New("price list not found")
+	ErrPriceListEmpty           = errors.New("price list must set prices or an adjustment")
+	ErrPriceListInvalidSchedule = errors.New("invalid price list schedule")
+	ErrPriceListUnknownProduct  = errors.New("price list product does not belong to the price list scope")
+)
+
+// PriceListSchedule describes when a price list applies. Times and days are in the
+// festival timezone; empty fields do not restrict the schedule.
+type PriceListSchedule struct {
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"` // Exclusive
+	Days       []int      `json:"days,omitempty"`       // 0 = Sunday ... 6 = Saturday
+	StartTime  string     `json:"startTime,omitempty"`  // HH:MM
+	EndTime    string     `json:"endTime,omitempty"`    // HH:MM, exclusive; before StartTime runs past midnight
+}
+
+// Validate checks the schedule fields
+func (s PriceListSchedule) Validate() error {
+	if s.ValidFrom != nil && s.ValidUntil != nil && !s.ValidUntil.After(*s.ValidFrom) {
+		return fmt.Errorf("%w: validUntil must be after validFrom", ErrPriceListInvalidSchedule)
+	}
+	for _, day := range s.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("%w: days must be between 0 (Sunday) and 6 (Saturday)", ErrPriceListInvalidSchedule)
+		}
+	}
+	if (s.StartTime == "") != (s.EndTime == "") {
+		return fmt.Errorf("%w: startTime and endTime must be set together", ErrPriceListInvalidSchedule)
+	}
+	if s.StartTime != "" {
+		start, err := parseClock(s.StartTime)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(s.EndTime)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("%w: startTime and endTime must differ", ErrPriceListInvalidSchedule)
+		}
+	}
+	return nil
+}
+
+// IsActiveAt reports whether the schedule applies at t. A daily window running past
+// midnight belongs to the day it started on, so a Friday 20:00-02:00 window also
+// applies early on Saturday.
+func (s PriceListSchedule) IsActiveAt(t time.Time, loc *time.Location) bool {
+	if s.ValidFrom != nil && t.Before(*s.ValidFrom) {
+		return false
+	}
+	if s.ValidUntil != nil && !t.Before(*s.ValidUntil) {
+		return false
+	}
+
+	local := t.In(loc)
+	day := local.Weekday()
+
+	if s.StartTime != "" {
+		start, err := parseClock(s.StartTime)
+		if err != nil {
+			return false
+		}
+		end, err := parseClock(s.EndTime)
+		if err != nil {
+			return false
+		}
+		minute := local.Hour()*60 + local.Minute()
+
+		switch {
+		case start < end:
+			if minute < start || minute >= end {
+				return false
+			}
+		case minute >= start:
+			// Evening part of an overnight window
+		case minute < end:
+			// Early morning part of an overnight window started the day before
+			day = (day + 6) % 7
+		default:
+			return false
+		}
+	}
+
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses an HH:MM time into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a HH:MM time", ErrPriceListInvalidSchedule, value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// PriceListEntry sets the price of a product while the price list is active
+type PriceListEntry struct {
+	ProductID uuid.UUID `json:"productId" binding:"required"`
+	Price     int64     `json:"price" binding:"min=0"` // Price in cents
+}
+
+// PriceList is a named set of prices for a stand or a whole festival that applies on
+// a schedule, e.g. matinee prices or a weekend surcharge. Products without an explicit
+// price get the list adjustment, if any. The scheduler job keeps Active in sync with
+// the schedule; orders use the active list with the highest priority.
+type PriceList struct {
+	ID              uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID      uuid.UUID            `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID         *uuid.UUID           `json:"standId,omitempty" gorm:"type:uuid;index"` // nil = festival-wide
+	Name            string               `json:"name" gorm:"not null"`
+	Priority        int                  `json:"priority" gorm:"not null;default:0"`
+	Schedule        PriceListSchedule    `json:"schedule" gorm:"type:jsonb;serializer:json"`
+	Prices          []PriceListEntry     `json:"prices" gorm:"type:jsonb;serializer:json"`
+	AdjustmentType  *PriceAdjustmentType `json:"adjustmentType,omitempty"`
+	AdjustmentValue int64                `json:"adjustmentValue"`
+	Enabled         bool                 `json:"enabled" gorm:"not null;default:true"`
+	Active          bool                 `json:"active" gorm:"not null;default:false"`
+	ActivatedAt     *time.Time           `json:"activatedAt,omitempty"`
+	CreatedBy       *uuid.UUID           `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
+}
+
+func (PriceList) TableName() string {
+	return "price_lists"
+}
+
+// PriceFor returns the price of a product under this price list
+func (l *PriceList) PriceFor(p *Product) int64 {
+	for _, entry := range l.Prices {
+		if entry.ProductID == p.ID {
+			return entry.Price
+		}
+	}
+	if l.AdjustmentType != nil {
+		if price, err := AdjustPrice(p.Price, *l.AdjustmentType, l.AdjustmentValue); err == nil {
+			return price
+		}
+	}
+	return p.Price
+}
+
+// SelectPriceList picks the price list that applies to a stand among active lists:
+// the highest priority wins, then stand lists over festival-wide ones, then the most
+// recently created. It returns nil if no list applies.
+func SelectPriceList(lists []PriceList, standID uuid.UUID) *PriceList {
+	var selected *PriceList
+	for i := range lists {
+		l := &lists[i]
+		if l.StandID != nil && *l.StandID != standID {
+			continue
+		}
+		if selected == nil || priceListBefore(l, selected) {
+			selected = l
+		}
+	}
+	return selected
+}
+
+func priceListBefore(a, b *PriceList) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if (a.StandID != nil) != (b.StandID != nil) {
+		return a.StandID != nil
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+// CreatePriceListRequest represents the request to create a price list
+type CreatePriceListRequest struct {
+	Name            string               `json:"name" binding:"required"`
+	StandID         *uuid.UUID           `json:"standId,omitempty"`
+	Priority        int                  `json:"priority"`
+	Schedule        PriceListSchedule    `json:"schedule"`
+	Prices          []PriceListEntry     `json:"prices,omitempty" binding:"dive"`
+	AdjustmentType  *PriceAdjustmentType `json:"adjustmentType,omitempty" binding:"omitempty,oneof=FIXED_AMOUNT PERCENTAGE"`
+	AdjustmentValue int64                `json:"adjustmentValue"`
+	Enabled         *bool                `json:"enabled,omitempty"` // Defaults to true
+}
+
+// UpdatePriceListRequest represents the request to update a price list
+type UpdatePriceListRequest struct {
+	Name            *string              `json:"name,omitempty"`
+	Priority        *int                 `json:"priority,omitempty"`
+	Schedule        *PriceListSchedule   `json:"schedule,omitempty"`
+	Prices          *[]PriceListEntry    `json:"prices,omitempty"`
+	AdjustmentType  *PriceAdjustmentType `json:"adjustmentType,omitempty" binding:"omitempty,oneof=FIXED_AMOUNT PERCENTAGE"`
+	AdjustmentValue *int64               `json:"adjustmentValue,omitempty"`
+	ClearAdjustment bool                 `json:"clearAdjustment,omitempty"`
+	Enabled         *bool                `json:"enabled,omitempty"`
+}
+
+// PriceListResponse represents the API response for a price list
+type PriceListResponse struct {
+	ID              uuid.UUID            `json:"id"`
+	FestivalID      uuid.UUID            `json:"festivalId"`
+	StandID         *uuid.UUID           `json:"standId,omitempty"`
+	Name            string               `json:"name"`
+	Priority        int                  `json:"priority"`
+	Schedule        PriceListSchedule    `json:"schedule"`
+	Prices          []PriceListEntry     `json:"prices"`
+	AdjustmentType  *PriceAdjustmentType `json:"adjustmentType,omitempty"`
+	AdjustmentValue int64                `json:"adjustmentValue"`
+	Enabled         bool                 `json:"enabled"`
+	Active          bool                 `json:"active"`
+	ActivatedAt     string               `json:"activatedAt,omitempty"`
+	CreatedAt       string               `json:"createdAt"`
+	UpdatedAt       string               `json:"updatedAt"`
+}
+
+func (l *PriceList) ToResponse() PriceListResponse {
+	prices := l.Prices
+	if prices == nil {
+		prices = []PriceListEntry{}
+	}
+
+	return PriceListResponse{
+		ID:              l.ID,
+		FestivalID:      l.FestivalID,
+		StandID:         l.StandID,
+		Name:            l.Name,
+		Priority:        l.Priority,
+		Schedule:        l.Schedule,
+		Prices:          prices,
+		AdjustmentType:  l.AdjustmentType,
+		AdjustmentValue: l.AdjustmentValue,
+		Enabled:         l.Enabled,
+		Active:          l.Active,
+		ActivatedAt:     formatOptionalTime(l.ActivatedAt),
+		CreatedAt:       l.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       l.UpdatedAt.Format(time.RFC3339),
+	}
+}
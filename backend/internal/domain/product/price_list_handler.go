@@ -0,0 +1,266 @@
+package product
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type PriceListHandler struct {
+	service *PriceListService
+}
+
+func NewPriceListHandler(service *PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+func (h *PriceListHandler) RegisterRoutes(r *gin.RouterGroup) {
+	lists := r.Group("/products/price-lists")
+	{
+		lists.POST("", h.Create)
+		lists.GET("", h.List)
+		lists.GET("/active", h.GetActive)
+		lists.GET("/:priceListId", h.GetByID)
+		lists.PATCH("/:priceListId", h.Update)
+		lists.DELETE("/:priceListId", h.Delete)
+	}
+}
+
+// Create creates a price list
+// @Summary Create price list
+// @Description Create a named price list for a stand or the whole festival that applies on a schedule (e.g. matinee prices, weekend surcharge)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreatePriceListRequest true "Price list data"
+// @Success 201 {object} response.Response{data=PriceListResponse} "Price list created"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-lists [post]
+func (h *PriceListHandler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreatePriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		createdBy = &userID
+	}
+
+	list, err := h.service.Create(c.Request.Context(), festivalID, createdBy, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, list.ToResponse())
+}
+
+// List lists price lists
+// @Summary List price lists
+// @Description List the festival's price lists, optionally only those that apply to a stand
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]PriceListResponse} "Price lists"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-lists [get]
+func (h *PriceListHandler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var standID *uuid.UUID
+	if raw := c.Query("standId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+			return
+		}
+		standID = &id
+	}
+
+	lists, err := h.service.List(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	items := make([]PriceListResponse, len(lists))
+	for i, l := range lists {
+		items[i] = l.ToResponse()
+	}
+
+	response.OK(c, items)
+}
+
+// GetActive gets the price list in effect at a stand
+// @Summary Get active price list
+// @Description Get the price list currently applied to orders at a stand; null when the regular prices apply
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string true "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=PriceListResponse} "Active price list"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-lists/active [get]
+func (h *PriceListHandler) GetActive(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	standID, err := uuid.Parse(c.Query("standId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+		return
+	}
+
+	list, err := h.service.ActivePriceList(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+	if list == nil {
+		response.OK(c, nil)
+		return
+	}
+
+	response.OK(c, list.ToResponse())
+}
+
+// GetByID gets a price list
+// @Summary Get price list
+// @Description Get a price list with its schedule and prices
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param priceListId path string true "Price list ID" format(uuid)
+// @Success 200 {object} response.Response{data=PriceListResponse} "Price list"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Price list not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-lists/{priceListId} [get]
+func (h *PriceListHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("priceListId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid price list ID", nil)
+		return
+	}
+
+	list, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, list.ToResponse())
+}
+
+// Update updates a price list
+// @Summary Update price list
+// @Description Update a price list; its activation is re-evaluated immediately
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param priceListId path string true "Price list ID" format(uuid)
+// @Param request body UpdatePriceListRequest true "Fields to update"
+// @Success 200 {object} response.Response{data=PriceListResponse} "Updated price list"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Price list not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-lists/{priceListId} [patch]
+func (h *PriceListHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("priceListId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid price list ID", nil)
+		return
+	}
+
+	var req UpdatePriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	list, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, list.ToResponse())
+}
+
+// Delete deletes a price list
+// @Summary Delete price list
+// @Description Delete a price list; orders keep their reference to it
+// @Tags products
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param priceListId path string true "Price list ID" format(uuid)
+// @Success 204 "Price list deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Price list not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-lists/{priceListId} [delete]
+func (h *PriceListHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("priceListId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid price list ID", nil)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func (h *PriceListHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPriceListNotFound):
+		response.NotFound(c, "Price list not found")
+	case errors.Is(err, ErrPriceListEmpty):
+		response.BadRequest(c, "EMPTY_PRICE_LIST", err.Error(), nil)
+	case errors.Is(err, ErrPriceListInvalidSchedule):
+		response.BadRequest(c, "INVALID_SCHEDULE", err.Error(), nil)
+	case errors.Is(err, ErrPriceListUnknownProduct):
+		response.BadRequest(c, "UNKNOWN_PRODUCT", err.Error(), nil)
+	case errors.Is(err, ErrPriceUpdateNegative):
+		response.BadRequest(c, "NEGATIVE_PRICE", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
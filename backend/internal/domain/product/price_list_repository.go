@@ -0,0 +1,127 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriceListRepository persists scheduled price lists
+type PriceListRepository interface {
+	Create(ctx context.Context, list *PriceList) error
+	GetByID(ctx context.Context, id uuid.UUID) (*PriceList, error)
+	ListByFestival(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]PriceList, error)
+	Update(ctx context.Context, list *PriceList) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListSchedulable lists the lists the scheduler may have to switch on or off
+	ListSchedulable(ctx context.Context, festivalID *uuid.UUID) ([]PriceList, error)
+	// ListActive lists the active lists of a festival that apply to a stand
+	ListActive(ctx context.Context, festivalID, standID uuid.UUID) ([]PriceList, error)
+	SetActive(ctx context.Context, ids []uuid.UUID, active bool, at time.Time) error
+	GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error)
+}
+
+type priceListRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceListRepository(db *gorm.DB) PriceListRepository {
+	return &priceListRepository{db: db}
+}
+
+func (r *priceListRepository) Create(ctx context.Context, list *PriceList) error {
+	return r.db.WithContext(ctx).Create(list).Error
+}
+
+func (r *priceListRepository) GetByID(ctx context.Context, id uuid.UUID) (*PriceList, error) {
+	var list PriceList
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&list).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+	return &list, nil
+}
+
+func (r *priceListRepository) ListByFestival(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]PriceList, error) {
+	var lists []PriceList
+
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ? OR stand_id IS NULL", *standID)
+	}
+
+	if err := query.Order("priority DESC, created_at DESC").Find(&lists).Error; err != nil {
+		return nil, fmt.Errorf("failed to list price lists: %w", err)
+	}
+	return lists, nil
+}
+
+func (r *priceListRepository) Update(ctx context.Context, list *PriceList) error {
+	return r.db.WithContext(ctx).Save(list).Error
+}
+
+func (r *priceListRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&PriceList{}, "id = ?", id).Error
+}
+
+func (r *priceListRepository) ListSchedulable(ctx context.Context, festivalID *uuid.UUID) ([]PriceList, error) {
+	var lists []PriceList
+
+	query := r.db.WithContext(ctx).Where("enabled = ? OR active = ?", true, true)
+	if festivalID != nil {
+		query = query.Where("festival_id = ?", *festivalID)
+	}
+
+	if err := query.Find(&lists).Error; err != nil {
+		return nil, fmt.Errorf("failed to list schedulable price lists: %w", err)
+	}
+	return lists, nil
+}
+
+func (r *priceListRepository) ListActive(ctx context.Context, festivalID, standID uuid.UUID) ([]PriceList, error) {
+	var lists []PriceList
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND active = ? AND enabled = ?", festivalID, true, true).
+		Where("stand_id = ? OR stand_id IS NULL", standID).
+		Find(&lists).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active price lists: %w", err)
+	}
+	return lists, nil
+}
+
+func (r *priceListRepository) SetActive(ctx context.Context, ids []uuid.UUID, active bool, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"active":     active,
+		"updated_at": at,
+	}
+	if active {
+		updates["activated_at"] = at
+	}
+
+	return r.db.WithContext(ctx).Model(&PriceList{}).Where("id IN ?", ids).Updates(updates).Error
+}
+
+func (r *priceListRepository) GetFestivalTimezone(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	var timezones []string
+	if err := r.db.WithContext(ctx).Raw(
+		"SELECT timezone FROM festivals WHERE id = ?",
+		festivalID,
+	).Scan(&timezones).Error; err != nil {
+		return "", fmt.Errorf("failed to get festival timezone: %w", err)
+	}
+	if len(timezones) == 0 {
+		return "", nil
+	}
+	return timezones[0], nil
+}
@@ -0,0 +1,310 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/rs/zerolog/log"
+)
+
+// Task type for the periodic price list activation job
+const TypeActivatePriceLists = "product:activate_price_lists"
+
+// ActivatePriceListsPayload limits the job to one festival; all festivals otherwise
+type ActivatePriceListsPayload struct {
+	FestivalID *uuid.UUID `json:"festivalId,omitempty"`
+}
+
+// NewActivatePriceListsTask creates a task that switches price lists on and off
+// according to their schedules
+func NewActivatePriceListsTask(payload ActivatePriceListsPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeActivatePriceLists, data), nil
+}
+
+// PriceListService manages scheduled price lists and resolves the prices in effect
+type PriceListService struct {
+	repo        PriceListRepository
+	productRepo Repository
+	clock       clock.Clock
+	sequencer   ChangeSequencer
+}
+
+// NewPriceListService creates a new price list service
+func NewPriceListService(repo PriceListRepository, productRepo Repository) *PriceListService {
+	return &PriceListService{
+		repo:        repo,
+		productRepo: productRepo,
+		clock:       clock.Wall{},
+	}
+}
+
+// SetClock sets the clock telling the time of festivals, so that the schedules of
+// sandbox festivals on a test clock follow their virtual time
+func (s *PriceListService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetChangeSequencer counts the price list edits and activations for the POS terminals
+func (s *PriceListService) SetChangeSequencer(sequencer ChangeSequencer) {
+	s.sequencer = sequencer
+}
+
+// pricesChanged reports a price change of a festival to the sequencer
+func (s *PriceListService) pricesChanged(ctx context.Context, festivalID uuid.UUID) {
+	if s.sequencer != nil {
+		s.sequencer.PricesChanged(ctx, festivalID)
+	}
+}
+
+// Create creates a price list and immediately activates it if its schedule applies
+func (s *PriceListService) Create(ctx context.Context, festivalID uuid.UUID, createdBy *uuid.UUID, req CreatePriceListRequest) (*PriceList, error) {
+	now := time.Now()
+	list := &PriceList{
+		ID:              uuid.New(),
+		FestivalID:      festivalID,
+		StandID:         req.StandID,
+		Name:            req.Name,
+		Priority:        req.Priority,
+		Schedule:        req.Schedule,
+		Prices:          req.Prices,
+		AdjustmentType:  req.AdjustmentType,
+		AdjustmentValue: req.AdjustmentValue,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		CreatedBy:       createdBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if list.Prices == nil {
+		list.Prices = []PriceListEntry{}
+	}
+
+	if err := s.validate(ctx, list); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to create price list: %w", err)
+	}
+	s.pricesChanged(ctx, festivalID)
+
+	if err := s.RefreshActivation(ctx, &festivalID, now); err != nil {
+		return nil, err
+	}
+	return s.GetByID(ctx, list.ID)
+}
+
+// GetByID gets a price list by ID
+func (s *PriceListService) GetByID(ctx context.Context, id uuid.UUID) (*PriceList, error) {
+	list, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, ErrPriceListNotFound
+	}
+	return list, nil
+}
+
+// List lists the price lists of a festival, optionally only those that apply to a stand
+func (s *PriceListService) List(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]PriceList, error) {
+	return s.repo.ListByFestival(ctx, festivalID, standID)
+}
+
+// Update updates a price list and re-evaluates its activation
+func (s *PriceListService) Update(ctx context.Context, id uuid.UUID, req UpdatePriceListRequest) (*PriceList, error) {
+	list, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+	if req.Priority != nil {
+		list.Priority = *req.Priority
+	}
+	if req.Schedule != nil {
+		list.Schedule = *req.Schedule
+	}
+	if req.Prices != nil {
+		list.Prices = *req.Prices
+	}
+	if req.ClearAdjustment {
+		list.AdjustmentType = nil
+		list.AdjustmentValue = 0
+	}
+	if req.AdjustmentType != nil {
+		list.AdjustmentType = req.AdjustmentType
+	}
+	if req.AdjustmentValue != nil {
+		list.AdjustmentValue = *req.AdjustmentValue
+	}
+	if req.Enabled != nil {
+		list.Enabled = *req.Enabled
+	}
+
+	if err := s.validate(ctx, list); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list.UpdatedAt = now
+	if err := s.repo.Update(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to update price list: %w", err)
+	}
+	s.pricesChanged(ctx, list.FestivalID)
+
+	if err := s.RefreshActivation(ctx, &list.FestivalID, now); err != nil {
+		return nil, err
+	}
+	return s.GetByID(ctx, list.ID)
+}
+
+// Delete deletes a price list. Orders keep the reference for audit.
+func (s *PriceListService) Delete(ctx context.Context, id uuid.UUID) error {
+	list, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.pricesChanged(ctx, list.FestivalID)
+	return nil
+}
+
+// ActivePriceList returns the price list in effect at a stand, or nil if the regular
+// product prices apply
+func (s *PriceListService) ActivePriceList(ctx context.Context, festivalID, standID uuid.UUID) (*PriceList, error) {
+	lists, err := s.repo.ListActive(ctx, festivalID, standID)
+	if err != nil {
+		return nil, err
+	}
+	return SelectPriceList(lists, standID), nil
+}
+
+// RefreshActivation switches price lists on or off so that exactly the enabled lists
+// whose schedule applies at now are active. The lists of festivals on a test clock are
+// evaluated at their virtual time instead.
+func (s *PriceListService) RefreshActivation(ctx context.Context, festivalID *uuid.UUID, now time.Time) error {
+	lists, err := s.repo.ListSchedulable(ctx, festivalID)
+	if err != nil {
+		return err
+	}
+	virtual, err := s.clock.Virtual(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get test clocks: %w", err)
+	}
+
+	locations := make(map[uuid.UUID]*time.Location)
+	var activate, deactivate []uuid.UUID
+	changed := make(map[uuid.UUID]bool)
+	for _, l := range lists {
+		loc, ok := locations[l.FestivalID]
+		if !ok {
+			name, err := s.repo.GetFestivalTimezone(ctx, l.FestivalID)
+			if err != nil {
+				return err
+			}
+			loc = tz.Load(name)
+			locations[l.FestivalID] = loc
+		}
+
+		at := now
+		if t, ok := virtual[l.FestivalID]; ok {
+			at = t
+		}
+
+		want := l.Enabled && l.Schedule.IsActiveAt(at, loc)
+		if want == l.Active {
+			continue
+		}
+		if want {
+			activate = append(activate, l.ID)
+		} else {
+			deactivate = append(deactivate, l.ID)
+		}
+		changed[l.FestivalID] = true
+		log.Info().
+			Str("price_list_id", l.ID.String()).
+			Str("festival_id", l.FestivalID.String()).
+			Bool("active", want).
+			Msg("Price list activation changed")
+	}
+
+	if err := s.repo.SetActive(ctx, activate, true, now); err != nil {
+		return fmt.Errorf("failed to activate price lists: %w", err)
+	}
+	if err := s.repo.SetActive(ctx, deactivate, false, now); err != nil {
+		return fmt.Errorf("failed to deactivate price lists: %w", err)
+	}
+	for id := range changed {
+		s.pricesChanged(ctx, id)
+	}
+	return nil
+}
+
+// HandleActivatePriceLists processes the periodic price list activation task
+func (s *PriceListService) HandleActivatePriceLists(ctx context.Context, t *asynq.Task) error {
+	var payload ActivatePriceListsPayload
+	if len(t.Payload()) > 0 {
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+
+	return s.RefreshActivation(ctx, payload.FestivalID, time.Now())
+}
+
+// validate checks the schedule, the adjustment and that every priced product belongs
+// to the festival and, for stand price lists, to the stand
+func (s *PriceListService) validate(ctx context.Context, list *PriceList) error {
+	if err := list.Schedule.Validate(); err != nil {
+		return err
+	}
+	if len(list.Prices) == 0 && list.AdjustmentType == nil {
+		return ErrPriceListEmpty
+	}
+	if list.AdjustmentType != nil && *list.AdjustmentType == PriceAdjustmentSetPrice {
+		return fmt.Errorf("%w: SET_PRICE adjustments need explicit prices", ErrPriceListEmpty)
+	}
+	if len(list.Prices) == 0 {
+		return nil
+	}
+
+	filter := PriceUpdateFilter{ProductIDs: make([]uuid.UUID, len(list.Prices))}
+	for i, entry := range list.Prices {
+		if entry.Price < 0 {
+			return ErrPriceUpdateNegative
+		}
+		filter.ProductIDs[i] = entry.ProductID
+	}
+	if list.StandID != nil {
+		filter.StandIDs = []uuid.UUID{*list.StandID}
+	}
+
+	products, err := s.productRepo.ListByFilter(ctx, list.FestivalID, filter)
+	if err != nil {
+		return err
+	}
+	known := make(map[uuid.UUID]bool, len(products))
+	for _, p := range products {
+		known[p.ID] = true
+	}
+	for _, entry := range list.Prices {
+		if !known[entry.ProductID] {
+			return fmt.Errorf("%w: %s", ErrPriceListUnknownProduct, entry.ProductID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package product
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPriceListSchedule_IsActiveAt tests schedule matching in the festival timezone
+func TestPriceListSchedule_IsActiveAt(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	// Friday 7 June 2024
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 6, day, hour, minute, 0, 0, loc)
+	}
+	validFrom := at(7, 0, 0)
+	validUntil := at(10, 0, 0)
+
+	tests := []struct {
+		name     string
+		schedule PriceListSchedule
+		at       time.Time
+		want     bool
+	}{
+		{"empty schedule always applies", PriceListSchedule{}, at(7, 12, 0), true},
+		{"before validity", PriceListSchedule{ValidFrom: &validFrom}, at(6, 23, 59), false},
+		{"validity end is exclusive", PriceListSchedule{ValidUntil: &validUntil}, at(10, 0, 0), false},
+		{"inside daily window", PriceListSchedule{StartTime: "12:00", EndTime: "17:00"}, at(7, 14, 30), true},
+		{"window end is exclusive", PriceListSchedule{StartTime: "12:00", EndTime: "17:00"}, at(7, 17, 0), false},
+		{"weekend only on saturday", PriceListSchedule{Days: []int{0, 6}}, at(8, 10, 0), true},
+		{"weekend only on friday", PriceListSchedule{Days: []int{0, 6}}, at(7, 10, 0), false},
+		{"overnight window evening", PriceListSchedule{Days: []int{5}, StartTime: "20:00", EndTime: "02:00"}, at(7, 22, 0), true},
+		{"overnight window belongs to start day", PriceListSchedule{Days: []int{5}, StartTime: "20:00", EndTime: "02:00"}, at(8, 1, 30), true},
+		{"overnight window next morning of other day", PriceListSchedule{Days: []int{5}, StartTime: "20:00", EndTime: "02:00"}, at(7, 1, 30), false},
+		{"overnight window afternoon", PriceListSchedule{StartTime: "20:00", EndTime: "02:00"}, at(7, 15, 0), false},
+		{"uses festival local time", PriceListSchedule{StartTime: "12:00", EndTime: "13:00"}, time.Date(2024, 6, 7, 10, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.schedule.IsActiveAt(tt.at, loc))
+		})
+	}
+}
+
+// TestPriceListSchedule_Validate tests schedule validation
+func TestPriceListSchedule_Validate(t *testing.T) {
+	assert.NoError(t, PriceListSchedule{StartTime: "20:00", EndTime: "02:00", Days: []int{5, 6}}.Validate())
+	assert.ErrorIs(t, PriceListSchedule{StartTime: "20:00"}.Validate(), ErrPriceListInvalidSchedule)
+	assert.ErrorIs(t, PriceListSchedule{StartTime: "8pm", EndTime: "23:00"}.Validate(), ErrPriceListInvalidSchedule)
+	assert.ErrorIs(t, PriceListSchedule{Days: []int{7}}.Validate(), ErrPriceListInvalidSchedule)
+}
+
+// TestSelectPriceList tests which active price list applies to a stand
+func TestSelectPriceList(t *testing.T) {
+	standID := uuid.New()
+	otherStand := uuid.New()
+	now := time.Now()
+
+	festivalWide := PriceList{ID: uuid.New(), Priority: 1, CreatedAt: now}
+	standList := PriceList{ID: uuid.New(), StandID: &standID, Priority: 1, CreatedAt: now.Add(-time.Hour)}
+	otherStandList := PriceList{ID: uuid.New(), StandID: &otherStand, Priority: 10, CreatedAt: now}
+	urgent := PriceList{ID: uuid.New(), Priority: 5, CreatedAt: now.Add(-2 * time.Hour)}
+
+	assert.Nil(t, SelectPriceList(nil, standID))
+	assert.Nil(t, SelectPriceList([]PriceList{otherStandList}, standID))
+	assert.Equal(t, standList.ID, SelectPriceList([]PriceList{festivalWide, standList, otherStandList}, standID).ID)
+	assert.Equal(t, urgent.ID, SelectPriceList([]PriceList{festivalWide, standList, urgent}, standID).ID)
+}
+
+// TestPriceList_PriceFor tests explicit prices and the list adjustment
+func TestPriceList_PriceFor(t *testing.T) {
+	beer := &Product{ID: uuid.New(), Price: 500}
+	water := &Product{ID: uuid.New(), Price: 200}
+	surcharge := PriceAdjustmentPercentage
+
+	list := PriceList{
+		Prices:          []PriceListEntry{{ProductID: beer.ID, Price: 400}},
+		AdjustmentType:  &surcharge,
+		AdjustmentValue: 10,
+	}
+	assert.Equal(t, int64(400), list.PriceFor(beer))
+	assert.Equal(t, int64(220), list.PriceFor(water))
+
+	list.AdjustmentType = nil
+	assert.Equal(t, int64(200), list.PriceFor(water))
+}
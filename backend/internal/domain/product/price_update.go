@@ -0,0 +1,177 @@
+package product
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Price update errors
+var (
+	ErrPriceUpdateNotFound     = errors.New("price update not found")
+	ErrPriceUpdateNotPending   = errors.New("price update is not scheduled")
+	ErrPriceUpdateNotApplied   = errors.New("price update has not been applied")
+	ErrPriceUpdateNoProducts   = errors.New("no products match the price update filter")
+	ErrPriceUpdateNegative     = errors.New("price adjustment would result in a negative price")
+	ErrPriceUpdateInvalidTimes = errors.New("revert time must be after the effective time")
+	ErrSchedulerUnavailable    = errors.New("scheduled price updates require a queue client")
+)
+
+// PriceAdjustmentType describes how a bulk price update changes product prices
+type PriceAdjustmentType string
+
+const (
+	PriceAdjustmentFixedAmount PriceAdjustmentType = "FIXED_AMOUNT" // Add value (in cents, may be negative)
+	PriceAdjustmentPercentage  PriceAdjustmentType = "PERCENTAGE"   // Add value percent (may be negative)
+	PriceAdjustmentSetPrice    PriceAdjustmentType = "SET_PRICE"    // Replace price with value (in cents)
+)
+
+// PriceUpdateStatus represents the lifecycle of a bulk price update
+type PriceUpdateStatus string
+
+const (
+	PriceUpdateStatusScheduled PriceUpdateStatus = "SCHEDULED"
+	PriceUpdateStatusApplied   PriceUpdateStatus = "APPLIED"
+	PriceUpdateStatusReverted  PriceUpdateStatus = "REVERTED"
+	PriceUpdateStatusCancelled PriceUpdateStatus = "CANCELLED"
+	PriceUpdateStatusFailed    PriceUpdateStatus = "FAILED"
+)
+
+// PriceUpdateFilter selects the products targeted by a bulk price update.
+// Empty fields do not restrict the selection.
+type PriceUpdateFilter struct {
+	StandIDs   []uuid.UUID       `json:"standIds,omitempty"`
+	Categories []ProductCategory `json:"categories,omitempty"`
+	ProductIDs []uuid.UUID       `json:"productIds,omitempty"`
+}
+
+// PriceUpdateItem records the price change of a single product
+type PriceUpdateItem struct {
+	ProductID uuid.UUID       `json:"productId"`
+	StandID   uuid.UUID       `json:"standId"`
+	Name      string          `json:"name"`
+	Category  ProductCategory `json:"category"`
+	OldPrice  int64           `json:"oldPrice"`
+	NewPrice  int64           `json:"newPrice"`
+}
+
+// BulkPriceUpdate represents a (possibly scheduled) price change across many products
+type BulkPriceUpdate struct {
+	ID              uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID      uuid.UUID           `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Name            string              `json:"name" gorm:"not null"`
+	Filter          PriceUpdateFilter   `json:"filter" gorm:"type:jsonb;serializer:json"`
+	AdjustmentType  PriceAdjustmentType `json:"adjustmentType" gorm:"not null"`
+	AdjustmentValue int64               `json:"adjustmentValue" gorm:"not null"`
+	Status          PriceUpdateStatus   `json:"status" gorm:"not null;default:'SCHEDULED'"`
+	EffectiveAt     time.Time           `json:"effectiveAt" gorm:"not null"`
+	RevertAt        *time.Time          `json:"revertAt,omitempty"`
+	AppliedAt       *time.Time          `json:"appliedAt,omitempty"`
+	RevertedAt      *time.Time          `json:"revertedAt,omitempty"`
+	Items           []PriceUpdateItem   `json:"items" gorm:"type:jsonb;serializer:json"`
+	FailureReason   string              `json:"failureReason,omitempty"`
+	CreatedBy       *uuid.UUID          `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+func (BulkPriceUpdate) TableName() string {
+	return "bulk_price_updates"
+}
+
+// PreviewPriceUpdateRequest represents the request to preview a bulk price update
+type PreviewPriceUpdateRequest struct {
+	Filter          PriceUpdateFilter   `json:"filter"`
+	AdjustmentType  PriceAdjustmentType `json:"adjustmentType" binding:"required,oneof=FIXED_AMOUNT PERCENTAGE SET_PRICE"`
+	AdjustmentValue int64               `json:"adjustmentValue"`
+}
+
+// CreatePriceUpdateRequest represents the request to create a bulk price update.
+// A nil EffectiveAt applies the update immediately; a non-nil RevertAt schedules
+// the automatic rollback to the previous prices.
+type CreatePriceUpdateRequest struct {
+	PreviewPriceUpdateRequest
+	Name        string     `json:"name" binding:"required"`
+	EffectiveAt *time.Time `json:"effectiveAt,omitempty"`
+	RevertAt    *time.Time `json:"revertAt,omitempty"`
+}
+
+// PriceUpdatePreview represents the products affected by a bulk price update
+type PriceUpdatePreview struct {
+	AffectedCount int               `json:"affectedCount"`
+	Items         []PriceUpdateItem `json:"items"`
+}
+
+// BulkPriceUpdateResponse represents the API response for a bulk price update
+type BulkPriceUpdateResponse struct {
+	ID              uuid.UUID           `json:"id"`
+	FestivalID      uuid.UUID           `json:"festivalId"`
+	Name            string              `json:"name"`
+	Filter          PriceUpdateFilter   `json:"filter"`
+	AdjustmentType  PriceAdjustmentType `json:"adjustmentType"`
+	AdjustmentValue int64               `json:"adjustmentValue"`
+	Status          PriceUpdateStatus   `json:"status"`
+	EffectiveAt     string              `json:"effectiveAt"`
+	RevertAt        string              `json:"revertAt,omitempty"`
+	AppliedAt       string              `json:"appliedAt,omitempty"`
+	RevertedAt      string              `json:"revertedAt,omitempty"`
+	AffectedCount   int                 `json:"affectedCount"`
+	Items           []PriceUpdateItem   `json:"items"`
+	FailureReason   string              `json:"failureReason,omitempty"`
+	CreatedAt       string              `json:"createdAt"`
+}
+
+func (u *BulkPriceUpdate) ToResponse() BulkPriceUpdateResponse {
+	items := u.Items
+	if items == nil {
+		items = []PriceUpdateItem{}
+	}
+
+	return BulkPriceUpdateResponse{
+		ID:              u.ID,
+		FestivalID:      u.FestivalID,
+		Name:            u.Name,
+		Filter:          u.Filter,
+		AdjustmentType:  u.AdjustmentType,
+		AdjustmentValue: u.AdjustmentValue,
+		Status:          u.Status,
+		EffectiveAt:     u.EffectiveAt.Format(time.RFC3339),
+		RevertAt:        formatOptionalTime(u.RevertAt),
+		AppliedAt:       formatOptionalTime(u.AppliedAt),
+		RevertedAt:      formatOptionalTime(u.RevertedAt),
+		AffectedCount:   len(items),
+		Items:           items,
+		FailureReason:   u.FailureReason,
+		CreatedAt:       u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// AdjustPrice computes the new price of a product for the given adjustment.
+// Percentage adjustments are rounded to the nearest cent.
+func AdjustPrice(price int64, adjustmentType PriceAdjustmentType, value int64) (int64, error) {
+	var newPrice int64
+	switch adjustmentType {
+	case PriceAdjustmentFixedAmount:
+		newPrice = price + value
+	case PriceAdjustmentPercentage:
+		newPrice = price + int64(math.Round(float64(price)*float64(value)/100))
+	case PriceAdjustmentSetPrice:
+		newPrice = value
+	default:
+		return 0, errors.New("unknown price adjustment type")
+	}
+
+	if newPrice < 0 {
+		return 0, ErrPriceUpdateNegative
+	}
+	return newPrice, nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
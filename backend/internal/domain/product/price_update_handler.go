@@ -0,0 +1,262 @@
+package product
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type PriceUpdateHandler struct {
+	service *PriceUpdateService
+}
+
+func NewPriceUpdateHandler(service *PriceUpdateService) *PriceUpdateHandler {
+	return &PriceUpdateHandler{service: service}
+}
+
+func (h *PriceUpdateHandler) RegisterRoutes(r *gin.RouterGroup) {
+	updates := r.Group("/products/price-updates")
+	{
+		updates.POST("/preview", h.Preview)
+		updates.POST("", h.Create)
+		updates.GET("", h.List)
+		updates.GET("/:updateId", h.GetByID)
+		updates.POST("/:updateId/cancel", h.Cancel)
+		updates.POST("/:updateId/revert", h.Revert)
+	}
+}
+
+// Preview previews a bulk price update
+// @Summary Preview bulk price update
+// @Description List the products matched by a bulk price update filter with their current and new prices
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body PreviewPriceUpdateRequest true "Filter and adjustment"
+// @Success 200 {object} response.Response{data=PriceUpdatePreview} "Affected products"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-updates/preview [post]
+func (h *PriceUpdateHandler) Preview(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req PreviewPriceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	preview, err := h.service.Preview(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, preview)
+}
+
+// Create creates a bulk price update
+// @Summary Create bulk price update
+// @Description Apply a price change to all matching products now or at a scheduled time, with optional automatic rollback
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreatePriceUpdateRequest true "Price update data"
+// @Success 201 {object} response.Response{data=BulkPriceUpdateResponse} "Price update created"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-updates [post]
+func (h *PriceUpdateHandler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreatePriceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		createdBy = &userID
+	}
+
+	update, err := h.service.Create(c.Request.Context(), festivalID, createdBy, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, update.ToResponse())
+}
+
+// List lists bulk price updates
+// @Summary List bulk price updates
+// @Description Get paginated list of bulk price updates for the festival
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]BulkPriceUpdateResponse,meta=response.Meta} "Price updates"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-updates [get]
+func (h *PriceUpdateHandler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	updates, total, err := h.service.List(c.Request.Context(), festivalID, page, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	items := make([]BulkPriceUpdateResponse, len(updates))
+	for i, u := range updates {
+		items[i] = u.ToResponse()
+	}
+
+	response.OKWithMeta(c, items, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// GetByID gets a bulk price update
+// @Summary Get bulk price update
+// @Description Get a bulk price update with the recorded per-product price changes
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param updateId path string true "Price update ID" format(uuid)
+// @Success 200 {object} response.Response{data=BulkPriceUpdateResponse} "Price update"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Price update not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-updates/{updateId} [get]
+func (h *PriceUpdateHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("updateId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid price update ID", nil)
+		return
+	}
+
+	update, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, update.ToResponse())
+}
+
+// Cancel cancels a scheduled bulk price update
+// @Summary Cancel bulk price update
+// @Description Cancel a price update that has not been applied yet
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param updateId path string true "Price update ID" format(uuid)
+// @Success 200 {object} response.Response{data=BulkPriceUpdateResponse} "Cancelled price update"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Price update not found"
+// @Failure 409 {object} response.ErrorResponse "Price update is not scheduled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-updates/{updateId}/cancel [post]
+func (h *PriceUpdateHandler) Cancel(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("updateId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid price update ID", nil)
+		return
+	}
+
+	update, err := h.service.Cancel(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, update.ToResponse())
+}
+
+// Revert rolls back an applied bulk price update
+// @Summary Revert bulk price update
+// @Description Restore the prices recorded when the update was applied
+// @Tags products
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param updateId path string true "Price update ID" format(uuid)
+// @Success 200 {object} response.Response{data=BulkPriceUpdateResponse} "Reverted price update"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Price update not found"
+// @Failure 409 {object} response.ErrorResponse "Price update has not been applied"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/products/price-updates/{updateId}/revert [post]
+func (h *PriceUpdateHandler) Revert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("updateId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid price update ID", nil)
+		return
+	}
+
+	update, err := h.service.Revert(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, update.ToResponse())
+}
+
+func (h *PriceUpdateHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPriceUpdateNotFound):
+		response.NotFound(c, "Price update not found")
+	case errors.Is(err, ErrPriceUpdateNotPending):
+		response.Conflict(c, "PRICE_UPDATE_NOT_SCHEDULED", err.Error())
+	case errors.Is(err, ErrPriceUpdateNotApplied):
+		response.Conflict(c, "PRICE_UPDATE_NOT_APPLIED", err.Error())
+	case errors.Is(err, ErrPriceUpdateNoProducts):
+		response.BadRequest(c, "NO_MATCHING_PRODUCTS", err.Error(), nil)
+	case errors.Is(err, ErrPriceUpdateNegative):
+		response.BadRequest(c, "NEGATIVE_PRICE", err.Error(), nil)
+	case errors.Is(err, ErrPriceUpdateInvalidTimes):
+		response.BadRequest(c, "INVALID_SCHEDULE", err.Error(), nil)
+	case errors.Is(err, ErrSchedulerUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,62 @@
+package product
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriceUpdateRepository persists bulk price updates
+type PriceUpdateRepository interface {
+	Create(ctx context.Context, update *BulkPriceUpdate) error
+	GetByID(ctx context.Context, id uuid.UUID) (*BulkPriceUpdate, error)
+	ListByFestival(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]BulkPriceUpdate, int64, error)
+	Update(ctx context.Context, update *BulkPriceUpdate) error
+}
+
+type priceUpdateRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceUpdateRepository(db *gorm.DB) PriceUpdateRepository {
+	return &priceUpdateRepository{db: db}
+}
+
+func (r *priceUpdateRepository) Create(ctx context.Context, update *BulkPriceUpdate) error {
+	return r.db.WithContext(ctx).Create(update).Error
+}
+
+func (r *priceUpdateRepository) GetByID(ctx context.Context, id uuid.UUID) (*BulkPriceUpdate, error) {
+	var update BulkPriceUpdate
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&update).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get price update: %w", err)
+	}
+	return &update, nil
+}
+
+func (r *priceUpdateRepository) ListByFestival(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]BulkPriceUpdate, int64, error) {
+	var updates []BulkPriceUpdate
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&BulkPriceUpdate{}).Where("festival_id = ?", festivalID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count price updates: %w", err)
+	}
+
+	if err := query.Offset(offset).Limit(limit).Order("effective_at DESC").Find(&updates).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list price updates: %w", err)
+	}
+
+	return updates, total, nil
+}
+
+func (r *priceUpdateRepository) Update(ctx context.Context, update *BulkPriceUpdate) error {
+	return r.db.WithContext(ctx).Save(update).Error
+}
@@ -0,0 +1,378 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/rs/zerolog/log"
+)
+
+// Task type constants for scheduled price updates
+const (
+	TypeApplyPriceUpdate  = "product:apply_price_update"
+	TypeRevertPriceUpdate = "product:revert_price_update"
+)
+
+// PriceUpdateTaskPayload identifies the price update processed by a task
+type PriceUpdateTaskPayload struct {
+	UpdateID uuid.UUID `json:"updateId"`
+}
+
+// NewApplyPriceUpdateTask creates a task that applies a scheduled price update
+func NewApplyPriceUpdateTask(updateID uuid.UUID) (*asynq.Task, error) {
+	data, err := json.Marshal(PriceUpdateTaskPayload{UpdateID: updateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeApplyPriceUpdate, data), nil
+}
+
+// NewRevertPriceUpdateTask creates a task that rolls back an applied price update
+func NewRevertPriceUpdateTask(updateID uuid.UUID) (*asynq.Task, error) {
+	data, err := json.Marshal(PriceUpdateTaskPayload{UpdateID: updateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeRevertPriceUpdate, data), nil
+}
+
+// PriceUpdateService handles bulk and scheduled product price changes
+type PriceUpdateService struct {
+	repo        PriceUpdateRepository
+	productRepo Repository
+	queueClient *queue.Client
+	sequencer   ChangeSequencer
+}
+
+// NewPriceUpdateService creates a new price update service.
+// queueClient may be nil, in which case only immediate updates are supported.
+func NewPriceUpdateService(repo PriceUpdateRepository, productRepo Repository, queueClient *queue.Client) *PriceUpdateService {
+	return &PriceUpdateService{
+		repo:        repo,
+		productRepo: productRepo,
+		queueClient: queueClient,
+	}
+}
+
+// Preview returns the products affected by a price update and their new prices
+func (s *PriceUpdateService) Preview(ctx context.Context, festivalID uuid.UUID, req PreviewPriceUpdateRequest) (*PriceUpdatePreview, error) {
+	items, err := s.computeItems(ctx, festivalID, req.Filter, req.AdjustmentType, req.AdjustmentValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceUpdatePreview{
+		AffectedCount: len(items),
+		Items:         items,
+	}, nil
+}
+
+// Create creates a bulk price update. Updates without an effective time (or with
+// one in the past) are applied immediately, others are scheduled on the queue.
+func (s *PriceUpdateService) Create(ctx context.Context, festivalID uuid.UUID, createdBy *uuid.UUID, req CreatePriceUpdateRequest) (*BulkPriceUpdate, error) {
+	now := time.Now()
+	effectiveAt := now
+	if req.EffectiveAt != nil && req.EffectiveAt.After(now) {
+		effectiveAt = *req.EffectiveAt
+	}
+
+	if req.RevertAt != nil && !req.RevertAt.After(effectiveAt) {
+		return nil, ErrPriceUpdateInvalidTimes
+	}
+
+	scheduled := effectiveAt.After(now)
+	if (scheduled || req.RevertAt != nil) && s.queueClient == nil {
+		return nil, ErrSchedulerUnavailable
+	}
+
+	// Validate the filter and adjustment against the current catalog
+	if _, err := s.computeItems(ctx, festivalID, req.Filter, req.AdjustmentType, req.AdjustmentValue); err != nil {
+		return nil, err
+	}
+
+	update := &BulkPriceUpdate{
+		ID:              uuid.New(),
+		FestivalID:      festivalID,
+		Name:            req.Name,
+		Filter:          req.Filter,
+		AdjustmentType:  req.AdjustmentType,
+		AdjustmentValue: req.AdjustmentValue,
+		Status:          PriceUpdateStatusScheduled,
+		EffectiveAt:     effectiveAt,
+		RevertAt:        req.RevertAt,
+		Items:           []PriceUpdateItem{},
+		CreatedBy:       createdBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := s.repo.Create(ctx, update); err != nil {
+		return nil, fmt.Errorf("failed to create price update: %w", err)
+	}
+
+	if !scheduled {
+		return s.Apply(ctx, update.ID)
+	}
+
+	task, err := NewApplyPriceUpdateTask(update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.queueClient.EnqueueScheduled(ctx, task, effectiveAt,
+		asynq.TaskID(fmt.Sprintf("price_update_apply_%s", update.ID)),
+		asynq.Queue(queue.QueueCritical),
+		asynq.MaxRetry(5),
+	); err != nil {
+		return nil, fmt.Errorf("failed to schedule price update: %w", err)
+	}
+
+	return update, nil
+}
+
+// GetByID gets a price update by ID
+func (s *PriceUpdateService) GetByID(ctx context.Context, id uuid.UUID) (*BulkPriceUpdate, error) {
+	update, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update == nil {
+		return nil, ErrPriceUpdateNotFound
+	}
+	return update, nil
+}
+
+// List lists price updates for a festival
+func (s *PriceUpdateService) List(ctx context.Context, festivalID uuid.UUID, page, perPage int) ([]BulkPriceUpdate, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	offset := (page - 1) * perPage
+	return s.repo.ListByFestival(ctx, festivalID, offset, perPage)
+}
+
+// Cancel cancels a scheduled price update before it is applied.
+// The queued task is left in place and becomes a no-op when it runs.
+func (s *PriceUpdateService) Cancel(ctx context.Context, id uuid.UUID) (*BulkPriceUpdate, error) {
+	update, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Status != PriceUpdateStatusScheduled {
+		return nil, ErrPriceUpdateNotPending
+	}
+
+	update.Status = PriceUpdateStatusCancelled
+	update.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, update); err != nil {
+		return nil, fmt.Errorf("failed to cancel price update: %w", err)
+	}
+	return update, nil
+}
+
+// SetChangeSequencer counts the applied and reverted price updates for the POS terminals
+func (s *PriceUpdateService) SetChangeSequencer(sequencer ChangeSequencer) {
+	s.sequencer = sequencer
+}
+
+// Apply applies a scheduled price update, recording the previous prices so the
+// change can be rolled back, and schedules the automatic rollback if requested.
+func (s *PriceUpdateService) Apply(ctx context.Context, id uuid.UUID) (*BulkPriceUpdate, error) {
+	update, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Status != PriceUpdateStatusScheduled {
+		return nil, ErrPriceUpdateNotPending
+	}
+
+	items, err := s.computeItems(ctx, update.FestivalID, update.Filter, update.AdjustmentType, update.AdjustmentValue)
+	if err != nil {
+		s.markFailed(ctx, update, err)
+		return nil, err
+	}
+
+	prices := make(map[uuid.UUID]int64, len(items))
+	for _, item := range items {
+		prices[item.ProductID] = item.NewPrice
+	}
+
+	if err := s.productRepo.UpdatePrices(ctx, prices); err != nil {
+		s.markFailed(ctx, update, err)
+		return nil, fmt.Errorf("failed to apply price update: %w", err)
+	}
+	if s.sequencer != nil {
+		s.sequencer.PricesChanged(ctx, update.FestivalID)
+	}
+
+	now := time.Now()
+	update.Items = items
+	update.Status = PriceUpdateStatusApplied
+	update.AppliedAt = &now
+	update.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, update); err != nil {
+		return nil, fmt.Errorf("failed to save price update: %w", err)
+	}
+
+	if update.RevertAt != nil && s.queueClient != nil {
+		task, err := NewRevertPriceUpdateTask(update.ID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.queueClient.EnqueueScheduled(ctx, task, *update.RevertAt,
+			asynq.TaskID(fmt.Sprintf("price_update_revert_%s", update.ID)),
+			asynq.Queue(queue.QueueCritical),
+			asynq.MaxRetry(5),
+		); err != nil {
+			log.Error().Err(err).
+				Str("price_update_id", update.ID.String()).
+				Msg("Failed to schedule price update rollback")
+		}
+	}
+
+	log.Info().
+		Str("price_update_id", update.ID.String()).
+		Int("affected_products", len(items)).
+		Msg("Bulk price update applied")
+
+	return update, nil
+}
+
+// Revert restores the prices recorded when the update was applied. Products whose
+// price was changed manually since then are left untouched.
+func (s *PriceUpdateService) Revert(ctx context.Context, id uuid.UUID) (*BulkPriceUpdate, error) {
+	update, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Status != PriceUpdateStatusApplied {
+		return nil, ErrPriceUpdateNotApplied
+	}
+
+	ids := make([]uuid.UUID, len(update.Items))
+	for i, item := range update.Items {
+		ids[i] = item.ProductID
+	}
+
+	current, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	currentPrices := make(map[uuid.UUID]int64, len(current))
+	for _, p := range current {
+		currentPrices[p.ID] = p.Price
+	}
+
+	prices := make(map[uuid.UUID]int64, len(update.Items))
+	skipped := 0
+	for _, item := range update.Items {
+		price, exists := currentPrices[item.ProductID]
+		if !exists || price != item.NewPrice {
+			skipped++
+			continue
+		}
+		prices[item.ProductID] = item.OldPrice
+	}
+
+	if err := s.productRepo.UpdatePrices(ctx, prices); err != nil {
+		return nil, fmt.Errorf("failed to revert price update: %w", err)
+	}
+	if s.sequencer != nil {
+		s.sequencer.PricesChanged(ctx, update.FestivalID)
+	}
+
+	now := time.Now()
+	update.Status = PriceUpdateStatusReverted
+	update.RevertedAt = &now
+	update.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, update); err != nil {
+		return nil, fmt.Errorf("failed to save price update: %w", err)
+	}
+
+	log.Info().
+		Str("price_update_id", update.ID.String()).
+		Int("reverted_products", len(prices)).
+		Int("skipped_products", skipped).
+		Msg("Bulk price update reverted")
+
+	return update, nil
+}
+
+// HandleApplyPriceUpdate processes the scheduled apply task
+func (s *PriceUpdateService) HandleApplyPriceUpdate(ctx context.Context, t *asynq.Task) error {
+	var payload PriceUpdateTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	_, err := s.Apply(ctx, payload.UpdateID)
+	if err == ErrPriceUpdateNotPending || err == ErrPriceUpdateNotFound {
+		// Cancelled or already processed, nothing to retry
+		log.Info().Str("price_update_id", payload.UpdateID.String()).Msg("Skipping price update apply task")
+		return nil
+	}
+	return err
+}
+
+// HandleRevertPriceUpdate processes the scheduled rollback task
+func (s *PriceUpdateService) HandleRevertPriceUpdate(ctx context.Context, t *asynq.Task) error {
+	var payload PriceUpdateTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	_, err := s.Revert(ctx, payload.UpdateID)
+	if err == ErrPriceUpdateNotApplied || err == ErrPriceUpdateNotFound {
+		log.Info().Str("price_update_id", payload.UpdateID.String()).Msg("Skipping price update revert task")
+		return nil
+	}
+	return err
+}
+
+// computeItems resolves the filter and computes the new price of every matching product
+func (s *PriceUpdateService) computeItems(ctx context.Context, festivalID uuid.UUID, filter PriceUpdateFilter, adjustmentType PriceAdjustmentType, value int64) ([]PriceUpdateItem, error) {
+	products, err := s.productRepo.ListByFilter(ctx, festivalID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrPriceUpdateNoProducts
+	}
+
+	items := make([]PriceUpdateItem, 0, len(products))
+	for _, p := range products {
+		newPrice, err := AdjustPrice(p.Price, adjustmentType, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, p.Name)
+		}
+		items = append(items, PriceUpdateItem{
+			ProductID: p.ID,
+			StandID:   p.StandID,
+			Name:      p.Name,
+			Category:  p.Category,
+			OldPrice:  p.Price,
+			NewPrice:  newPrice,
+		})
+	}
+	return items, nil
+}
+
+func (s *PriceUpdateService) markFailed(ctx context.Context, update *BulkPriceUpdate, cause error) {
+	update.Status = PriceUpdateStatusFailed
+	update.FailureReason = cause.Error()
+	update.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, update); err != nil {
+		log.Error().Err(err).Str("price_update_id", update.ID.String()).Msg("Failed to mark price update as failed")
+	}
+}
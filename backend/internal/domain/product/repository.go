@@ -3,9 +3,11 @@ package product
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // StockUpdate represents a stock change for a product
@@ -21,11 +23,22 @@ type Repository interface {
 	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]Product, error)
 	ListByStand(ctx context.Context, standID uuid.UUID, offset, limit int) ([]Product, int64, error)
 	ListByCategory(ctx context.Context, standID uuid.UUID, category ProductCategory) ([]Product, error)
+	ListByCategoryIDs(ctx context.Context, standID uuid.UUID, categoryIDs []uuid.UUID) ([]Product, error)
 	Update(ctx context.Context, product *Product) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	UpdateStock(ctx context.Context, id uuid.UUID, delta int) error
 	// UpdateStockBulk atomically updates stock for multiple products in a single transaction
 	UpdateStockBulk(ctx context.Context, updates []StockUpdate) error
+	// ListByFilter lists the festival's products matching a bulk price update filter
+	ListByFilter(ctx context.Context, festivalID uuid.UUID, filter PriceUpdateFilter) ([]Product, error)
+	// UpdatePrices sets the price of multiple products in a single transaction
+	UpdatePrices(ctx context.Context, prices map[uuid.UUID]int64) error
+	// ListMenu lists the products of a stand passing a menu filter
+	ListMenu(ctx context.Context, standID uuid.UUID, filter MenuFilter, offset, limit int) ([]Product, int64, error)
+	// CountDeclarations counts the festival's products in the categories, and those with their allergens declared
+	CountDeclarations(ctx context.Context, festivalID uuid.UUID, categories []ProductCategory) (total, declared int64, err error)
+	// ListUndeclared lists the festival's products in the categories without their allergens declared
+	ListUndeclared(ctx context.Context, festivalID uuid.UUID, categories []ProductCategory) ([]UndeclaredProduct, error)
 }
 
 type repository struct {
@@ -94,6 +107,18 @@ func (r *repository) ListByCategory(ctx context.Context, standID uuid.UUID, cate
 	return products, nil
 }
 
+func (r *repository) ListByCategoryIDs(ctx context.Context, standID uuid.UUID, categoryIDs []uuid.UUID) ([]Product, error) {
+	var products []Product
+	err := r.db.WithContext(ctx).
+		Where("stand_id = ? AND category_id IN ? AND status = ?", standID, categoryIDs, ProductStatusActive).
+		Order("sort_order ASC, name ASC").
+		Find(&products).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products by category IDs: %w", err)
+	}
+	return products, nil
+}
+
 func (r *repository) Update(ctx context.Context, product *Product) error {
 	return r.db.WithContext(ctx).Save(product).Error
 }
@@ -126,7 +151,10 @@ func (r *repository) UpdateStock(ctx context.Context, id uuid.UUID, delta int) e
 			return fmt.Errorf("failed to update stock: %w", err)
 		}
 
-		// Update status if out of stock
+		// Update status if out of stock; a recalled product stays recalled
+		if product.Status == ProductStatusRecalled {
+			return nil
+		}
 		if newStock == 0 {
 			if err := tx.Model(&product).Update("status", ProductStatusOutOfStock).Error; err != nil {
 				return fmt.Errorf("failed to update status: %w", err)
@@ -149,63 +177,182 @@ func (r *repository) UpdateStockBulk(ctx context.Context, updates []StockUpdate)
 	}
 
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Collect all product IDs
-		ids := make([]uuid.UUID, len(updates))
-		for i, u := range updates {
-			ids[i] = u.ProductID
+		return applyStockUpdates(tx, updates)
+	})
+}
+
+// applyStockUpdates locks the products and applies the stock deltas within tx, flagging
+// the products out of stock or back in stock
+func applyStockUpdates(tx *gorm.DB, updates []StockUpdate) error {
+	// Collect all product IDs
+	ids := make([]uuid.UUID, len(updates))
+	for i, u := range updates {
+		ids[i] = u.ProductID
+	}
+
+	// Lock and fetch all products in a single query
+	var products []Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Find(&products).Error; err != nil {
+		return fmt.Errorf("failed to lock products: %w", err)
+	}
+
+	// Create a map for quick lookup
+	productMap := make(map[uuid.UUID]*Product, len(products))
+	for i := range products {
+		productMap[products[i].ID] = &products[i]
+	}
+
+	// Process each update
+	for _, update := range updates {
+		product, exists := productMap[update.ProductID]
+		if !exists {
+			continue // Product not found, skip
 		}
 
-		// Lock and fetch all products in a single query
-		var products []Product
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
-			Where("id IN ?", ids).
-			Find(&products).Error; err != nil {
-			return fmt.Errorf("failed to lock products: %w", err)
+		if product.Stock == nil {
+			continue // Unlimited stock
 		}
 
-		// Create a map for quick lookup
-		productMap := make(map[uuid.UUID]*Product, len(products))
-		for i := range products {
-			productMap[products[i].ID] = &products[i]
+		newStock := *product.Stock + update.Delta
+		if newStock < 0 {
+			newStock = 0
 		}
 
-		// Process each update
-		for _, update := range updates {
-			product, exists := productMap[update.ProductID]
-			if !exists {
-				continue // Product not found, skip
-			}
+		// Determine new status; a recalled product stays recalled
+		var newStatus ProductStatus
+		if product.Status == ProductStatusRecalled {
+			newStatus = product.Status
+		} else if newStock == 0 {
+			newStatus = ProductStatusOutOfStock
+		} else if product.Status == ProductStatusOutOfStock {
+			newStatus = ProductStatusActive
+		} else {
+			newStatus = product.Status
+		}
 
-			if product.Stock == nil {
-				continue // Unlimited stock
-			}
+		// Update in single query
+		if err := tx.Model(&Product{}).
+			Where("id = ?", update.ProductID).
+			Updates(map[string]interface{}{
+				"stock":  newStock,
+				"status": newStatus,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update product %s stock: %w", update.ProductID, err)
+		}
+	}
 
-			newStock := *product.Stock + update.Delta
-			if newStock < 0 {
-				newStock = 0
-			}
+	return nil
+}
 
-			// Determine new status
-			var newStatus ProductStatus
-			if newStock == 0 {
-				newStatus = ProductStatusOutOfStock
-			} else if product.Status == ProductStatusOutOfStock {
-				newStatus = ProductStatusActive
-			} else {
-				newStatus = product.Status
-			}
+// ListByFilter lists the festival's products matching a bulk price update filter
+func (r *repository) ListByFilter(ctx context.Context, festivalID uuid.UUID, filter PriceUpdateFilter) ([]Product, error) {
+	var products []Product
 
-			// Update in single query
+	query := r.db.WithContext(ctx).
+		Model(&Product{}).
+		Joins("JOIN stands ON stands.id = products.stand_id").
+		Where("stands.festival_id = ?", festivalID)
+
+	if len(filter.StandIDs) > 0 {
+		query = query.Where("products.stand_id IN ?", filter.StandIDs)
+	}
+	if len(filter.Categories) > 0 {
+		query = query.Where("products.category IN ?", filter.Categories)
+	}
+	if len(filter.ProductIDs) > 0 {
+		query = query.Where("products.id IN ?", filter.ProductIDs)
+	}
+
+	if err := query.Order("products.stand_id ASC, products.sort_order ASC, products.name ASC").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to list products by filter: %w", err)
+	}
+	return products, nil
+}
+
+// UpdatePrices sets the price of multiple products in a single transaction
+func (r *repository) UpdatePrices(ctx context.Context, prices map[uuid.UUID]int64) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for id, price := range prices {
 			if err := tx.Model(&Product{}).
-				Where("id = ?", update.ProductID).
+				Where("id = ?", id).
 				Updates(map[string]interface{}{
-					"stock":  newStock,
-					"status": newStatus,
+					"price":      price,
+					"updated_at": now,
 				}).Error; err != nil {
-				return fmt.Errorf("failed to update product %s stock: %w", update.ProductID, err)
+				return fmt.Errorf("failed to update product %s price: %w", id, err)
 			}
 		}
-
 		return nil
 	})
 }
+
+// ListMenu lists the products of a stand passing a menu filter. The allergens and
+// dietary tags are JSON arrays, matched with the jsonb containment operator.
+func (r *repository) ListMenu(ctx context.Context, standID uuid.UUID, filter MenuFilter, offset, limit int) ([]Product, int64, error) {
+	var products []Product
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&Product{}).Where("stand_id = ?", standID)
+	if len(filter.FreeOf) > 0 {
+		query = query.Where("allergens_declared_at IS NOT NULL")
+		for _, allergen := range filter.FreeOf {
+			query = query.Where("NOT (allergens @> ?::jsonb)", fmt.Sprintf(`["%s"]`, allergen))
+		}
+	}
+	for _, tag := range filter.Dietary {
+		query = query.Where("dietary_tags @> ?::jsonb", fmt.Sprintf(`["%s"]`, tag))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	if err := query.Offset(offset).Limit(limit).Order("sort_order ASC, name ASC").Find(&products).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list menu products: %w", err)
+	}
+
+	return products, total, nil
+}
+
+// CountDeclarations counts the festival's products in the categories, and those with
+// their allergens declared
+func (r *repository) CountDeclarations(ctx context.Context, festivalID uuid.UUID, categories []ProductCategory) (int64, int64, error) {
+	var counts struct {
+		Total    int64
+		Declared int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&Product{}).
+		Select("COUNT(*) AS total, COUNT(products.allergens_declared_at) AS declared").
+		Joins("JOIN stands ON stands.id = products.stand_id").
+		Where("stands.festival_id = ? AND products.category IN ?", festivalID, categories).
+		Scan(&counts).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count allergen declarations: %w", err)
+	}
+	return counts.Total, counts.Declared, nil
+}
+
+// ListUndeclared lists the festival's products in the categories without their allergens
+// declared, by stand with the products on sale first
+func (r *repository) ListUndeclared(ctx context.Context, festivalID uuid.UUID, categories []ProductCategory) ([]UndeclaredProduct, error) {
+	var products []UndeclaredProduct
+	err := r.db.WithContext(ctx).
+		Model(&Product{}).
+		Select("products.id AS product_id, products.name, products.stand_id, stands.name AS stand_name, products.category, products.status").
+		Joins("JOIN stands ON stands.id = products.stand_id").
+		Where("stands.festival_id = ? AND products.category IN ? AND products.allergens_declared_at IS NULL", festivalID, categories).
+		Order(fmt.Sprintf("stands.name ASC, products.status = '%s' DESC, products.sort_order ASC, products.name ASC", ProductStatusActive)).
+		Scan(&products).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list undeclared products: %w", err)
+	}
+	return products, nil
+}
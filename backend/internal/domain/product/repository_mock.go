@@ -52,6 +52,11 @@ func (m *MockRepository) ListByCategory(ctx context.Context, standID uuid.UUID,
 	return args.Get(0).([]Product), args.Error(1)
 }
 
+func (m *MockRepository) ListByCategoryIDs(ctx context.Context, standID uuid.UUID, categoryIDs []uuid.UUID) ([]Product, error) {
+	args := m.Called(ctx, standID, categoryIDs)
+	return args.Get(0).([]Product), args.Error(1)
+}
+
 func (m *MockRepository) Update(ctx context.Context, product *Product) error {
 	args := m.Called(ctx, product)
 	return args.Error(0)
@@ -66,3 +71,33 @@ func (m *MockRepository) UpdateStock(ctx context.Context, id uuid.UUID, delta in
 	args := m.Called(ctx, id, delta)
 	return args.Error(0)
 }
+
+func (m *MockRepository) UpdateStockBulk(ctx context.Context, updates []StockUpdate) error {
+	args := m.Called(ctx, updates)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListByFilter(ctx context.Context, festivalID uuid.UUID, filter PriceUpdateFilter) ([]Product, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Product), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePrices(ctx context.Context, prices map[uuid.UUID]int64) error {
+	args := m.Called(ctx, prices)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListMenu(ctx context.Context, standID uuid.UUID, filter MenuFilter, offset, limit int) ([]Product, int64, error) {
+	args := m.Called(ctx, standID, filter, offset, limit)
+	return args.Get(0).([]Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) CountDeclarations(ctx context.Context, festivalID uuid.UUID, categories []ProductCategory) (int64, int64, error) {
+	args := m.Called(ctx, festivalID, categories)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) ListUndeclared(ctx context.Context, festivalID uuid.UUID, categories []ProductCategory) ([]UndeclaredProduct, error) {
+	args := m.Called(ctx, festivalID, categories)
+	return args.Get(0).([]UndeclaredProduct), args.Error(1)
+}
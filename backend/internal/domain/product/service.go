@@ -7,25 +7,91 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 )
 
+// CategoryResolver resolves festival category hierarchy information
+type CategoryResolver interface {
+	DescendantIDs(ctx context.Context, categoryID uuid.UUID) ([]uuid.UUID, error)
+	DefaultTaxClass(ctx context.Context, categoryID uuid.UUID) (string, error)
+}
+
+// ActivityRecorder records product edits in the organizer feed; satisfied by activity.Service
+type ActivityRecorder interface {
+	RecordProductChange(ctx context.Context, standID, productID uuid.UUID, name, change string)
+}
+
+// LocaleResolver resolves the language the products of a stand are written in; satisfied
+// by stand.Service
+type LocaleResolver interface {
+	DefaultLocale(ctx context.Context, standID uuid.UUID) (string, error)
+}
+
+// ChangeSequencer counts the product and price changes the POS terminals compare to
+// know their cached menus are stale; satisfied by posdevice.Sequencer
+type ChangeSequencer interface {
+	ProductsChanged(ctx context.Context, standID uuid.UUID)
+	StandPricesChanged(ctx context.Context, standID uuid.UUID)
+	PricesChanged(ctx context.Context, festivalID uuid.UUID)
+}
+
 type Service struct {
-	repo Repository
+	repo       Repository
+	categories CategoryResolver
+	activity   ActivityRecorder
+	locales    LocaleResolver
+	sequencer  ChangeSequencer
 }
 
 func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetCategoryResolver enables category-based filtering and tax class defaults
+func (s *Service) SetCategoryResolver(resolver CategoryResolver) {
+	s.categories = resolver
+}
+
+// SetActivityRecorder reports product edits to the activity feed
+func (s *Service) SetActivityRecorder(recorder ActivityRecorder) {
+	s.activity = recorder
+}
+
+// SetLocaleResolver enables checking translations against the festival's default locale
+func (s *Service) SetLocaleResolver(resolver LocaleResolver) {
+	s.locales = resolver
+}
+
+// SetChangeSequencer counts the product edits for the POS terminals
+func (s *Service) SetChangeSequencer(sequencer ChangeSequencer) {
+	s.sequencer = sequencer
+}
+
+// standLocale returns the default locale of the festival of a stand, i18n.Default without a resolver
+func (s *Service) standLocale(ctx context.Context, standID uuid.UUID) (string, error) {
+	if s.locales == nil {
+		return i18n.Default, nil
+	}
+	return s.locales.DefaultLocale(ctx, standID)
+}
+
 // Create creates a new product
 func (s *Service) Create(ctx context.Context, req CreateProductRequest) (*Product, error) {
+	locale, err := s.standLocale(ctx, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &Product{
 		ID:          uuid.New(),
 		StandID:     req.StandID,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
+		CostPrice:   req.CostPrice,
 		Category:    req.Category,
+		CategoryID:  req.CategoryID,
+		TaxClass:    req.TaxClass,
 		ImageURL:    req.ImageURL,
 		SKU:         req.SKU,
 		Stock:       req.Stock,
@@ -40,15 +106,32 @@ func (s *Service) Create(ctx context.Context, req CreateProductRequest) (*Produc
 		product.Tags = []string{}
 	}
 
+	if err := applyDeclaration(product, req.Allergens, req.DietaryTags, req.Nutrition, product.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := applyTranslations(product, locale, req); err != nil {
+		return nil, err
+	}
+	if err := s.applyDefaultTaxClass(ctx, product); err != nil {
+		return nil, err
+	}
+
 	if err := s.repo.Create(ctx, product); err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
+	s.recordActivity(ctx, product, "created")
+	s.productsChanged(ctx, product.StandID, false)
 	return product, nil
 }
 
 // CreateBulk creates multiple products at once
 func (s *Service) CreateBulk(ctx context.Context, req BulkCreateProductRequest) ([]Product, error) {
+	locale, err := s.standLocale(ctx, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+
 	products := make([]Product, len(req.Products))
 
 	for i, p := range req.Products {
@@ -63,7 +146,10 @@ func (s *Service) CreateBulk(ctx context.Context, req BulkCreateProductRequest)
 			Name:        p.Name,
 			Description: p.Description,
 			Price:       p.Price,
+			CostPrice:   p.CostPrice,
 			Category:    p.Category,
+			CategoryID:  p.CategoryID,
+			TaxClass:    p.TaxClass,
 			ImageURL:    p.ImageURL,
 			SKU:         p.SKU,
 			Stock:       p.Stock,
@@ -73,11 +159,22 @@ func (s *Service) CreateBulk(ctx context.Context, req BulkCreateProductRequest)
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
+
+		if err := applyDeclaration(&products[i], p.Allergens, p.DietaryTags, p.Nutrition, products[i].CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := applyTranslations(&products[i], locale, p); err != nil {
+			return nil, err
+		}
+		if err := s.applyDefaultTaxClass(ctx, &products[i]); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.repo.CreateBulk(ctx, products); err != nil {
 		return nil, fmt.Errorf("failed to create products: %w", err)
 	}
+	s.productsChanged(ctx, req.StandID, false)
 
 	return products, nil
 }
@@ -112,11 +209,40 @@ func (s *Service) List(ctx context.Context, standID uuid.UUID, page, perPage int
 	return s.repo.ListByStand(ctx, standID, offset, perPage)
 }
 
+// ListMenu lists the products of a stand suiting an attendee
+func (s *Service) ListMenu(ctx context.Context, standID uuid.UUID, filter MenuFilter, page, perPage int) ([]Product, int64, error) {
+	if filter.IsZero() {
+		return s.List(ctx, standID, page, perPage)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+
+	offset := (page - 1) * perPage
+	return s.repo.ListMenu(ctx, standID, filter, offset, perPage)
+}
+
 // ListByCategory lists products by category
 func (s *Service) ListByCategory(ctx context.Context, standID uuid.UUID, category ProductCategory) ([]Product, error) {
 	return s.repo.ListByCategory(ctx, standID, category)
 }
 
+// ListByCategoryID lists active products of a stand in a festival category or any of its subcategories
+func (s *Service) ListByCategoryID(ctx context.Context, standID, categoryID uuid.UUID) ([]Product, error) {
+	categoryIDs := []uuid.UUID{categoryID}
+	if s.categories != nil {
+		ids, err := s.categories.DescendantIDs(ctx, categoryID)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs = ids
+	}
+	return s.repo.ListByCategoryIDs(ctx, standID, categoryIDs)
+}
+
 // Update updates a product
 func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateProductRequest) (*Product, error) {
 	product, err := s.repo.GetByID(ctx, id)
@@ -128,18 +254,36 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateProductReq
 	}
 
 	// Apply updates
-	if req.Name != nil {
-		product.Name = *req.Name
-	}
-	if req.Description != nil {
-		product.Description = *req.Description
+	if req.Name != nil || req.Description != nil || req.NameTranslations != nil || req.DescriptionTranslations != nil {
+		locale, err := s.standLocale(ctx, product.StandID)
+		if err != nil {
+			return nil, err
+		}
+		product.Name, product.NameTranslations, err = i18n.UpdateText(locale, product.Name, product.NameTranslations, req.Name, req.NameTranslations)
+		if err != nil {
+			return nil, err
+		}
+		product.Description, product.DescriptionTranslations, err = i18n.UpdateText(locale, product.Description, product.DescriptionTranslations, req.Description, req.DescriptionTranslations)
+		if err != nil {
+			return nil, err
+		}
 	}
+	priceChanged := req.Price != nil && *req.Price != product.Price
 	if req.Price != nil {
 		product.Price = *req.Price
 	}
+	if req.CostPrice != nil {
+		product.CostPrice = req.CostPrice
+	}
 	if req.Category != nil {
 		product.Category = *req.Category
 	}
+	if req.CategoryID != nil {
+		product.CategoryID = req.CategoryID
+	}
+	if req.TaxClass != nil {
+		product.TaxClass = *req.TaxClass
+	}
 	if req.ImageURL != nil {
 		product.ImageURL = *req.ImageURL
 	}
@@ -152,19 +296,32 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateProductReq
 	if req.SortOrder != nil {
 		product.SortOrder = *req.SortOrder
 	}
-	if req.Status != nil {
+	if req.Status != nil && *req.Status != product.Status {
+		if product.Status == ProductStatusRecalled || *req.Status == ProductStatusRecalled {
+			return nil, ErrProductRecalled
+		}
 		product.Status = *req.Status
 	}
 	if req.Tags != nil {
 		product.Tags = req.Tags
 	}
 
-	product.UpdatedAt = time.Now()
+	now := time.Now()
+	if err := applyDeclaration(product, req.Allergens, req.DietaryTags, req.Nutrition, now); err != nil {
+		return nil, err
+	}
+	if err := s.applyDefaultTaxClass(ctx, product); err != nil {
+		return nil, err
+	}
+
+	product.UpdatedAt = now
 
 	if err := s.repo.Update(ctx, product); err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
+	s.recordActivity(ctx, product, "updated")
+	s.productsChanged(ctx, product.StandID, priceChanged)
 	return product, nil
 }
 
@@ -178,12 +335,71 @@ func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 		return errors.ErrNotFound
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.recordActivity(ctx, product, "deleted")
+	s.productsChanged(ctx, product.StandID, false)
+	return nil
+}
+
+// productsChanged reports a product edit of a stand to the sequencer, and a price
+// change along with it
+func (s *Service) productsChanged(ctx context.Context, standID uuid.UUID, priceChanged bool) {
+	if s.sequencer == nil {
+		return
+	}
+	s.sequencer.ProductsChanged(ctx, standID)
+	if priceChanged {
+		s.sequencer.StandPricesChanged(ctx, standID)
+	}
+}
+
+// recordActivity reports a product edit to the activity feed
+func (s *Service) recordActivity(ctx context.Context, product *Product, change string) {
+	if s.activity != nil {
+		s.activity.RecordProductChange(ctx, product.StandID, product.ID, product.Name, change)
+	}
+}
+
+// AllergenReport lists the food and drink products of a festival whose allergens are
+// not declared, which may not be sold under the EU food information rules
+func (s *Service) AllergenReport(ctx context.Context, festivalID uuid.UUID) (*AllergenReport, error) {
+	required, declared, err := s.repo.CountDeclarations(ctx, festivalID, DeclarationCategories)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := s.repo.ListUndeclared(ctx, festivalID, DeclarationCategories)
+	if err != nil {
+		return nil, err
+	}
+	if missing == nil {
+		missing = []UndeclaredProduct{}
+	}
+
+	return &AllergenReport{
+		FestivalID:  festivalID,
+		GeneratedAt: time.Now(),
+		Required:    required,
+		Declared:    declared,
+		Compliant:   len(missing) == 0,
+		Missing:     missing,
+	}, nil
 }
 
-// UpdateStock updates product stock
+// UpdateStock updates product stock. Unlike the stock moves of sales, a restock is
+// reported to the sequencer.
 func (s *Service) UpdateStock(ctx context.Context, id uuid.UUID, delta int) error {
-	return s.repo.UpdateStock(ctx, id, delta)
+	if err := s.repo.UpdateStock(ctx, id, delta); err != nil {
+		return err
+	}
+	if s.sequencer != nil {
+		if product, err := s.repo.GetByID(ctx, id); err == nil && product != nil {
+			s.sequencer.ProductsChanged(ctx, product.StandID)
+		}
+	}
+	return nil
 }
 
 // DecrementStock decrements stock after a sale
@@ -217,3 +433,29 @@ func (s *Service) Deactivate(ctx context.Context, id uuid.UUID) (*Product, error
 	status := ProductStatusInactive
 	return s.Update(ctx, id, UpdateProductRequest{Status: &status})
 }
+
+// applyTranslations checks the translations of a new product and sets its name and
+// description to their text in the default locale
+func applyTranslations(product *Product, locale string, req CreateProductRequest) error {
+	var err error
+	product.Name, product.NameTranslations, err = i18n.UpdateText(locale, product.Name, nil, nil, req.NameTranslations)
+	if err != nil {
+		return err
+	}
+	product.Description, product.DescriptionTranslations, err = i18n.UpdateText(locale, product.Description, nil, nil, req.DescriptionTranslations)
+	return err
+}
+
+// applyDefaultTaxClass fills in the tax class from the product's category when none is set
+func (s *Service) applyDefaultTaxClass(ctx context.Context, product *Product) error {
+	if product.TaxClass != "" || product.CategoryID == nil || s.categories == nil {
+		return nil
+	}
+
+	taxClass, err := s.categories.DefaultTaxClass(ctx, *product.CategoryID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve default tax class: %w", err)
+	}
+	product.TaxClass = taxClass
+	return nil
+}
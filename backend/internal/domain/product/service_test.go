@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -795,3 +796,194 @@ func TestService_CreateBulk(t *testing.T) {
 		})
 	}
 }
+
+type fixedLocale string
+
+func (l fixedLocale) DefaultLocale(ctx context.Context, standID uuid.UUID) (string, error) {
+	return string(l), nil
+}
+
+// TestService_Translations tests that translations keep the text in the festival's default locale
+func TestService_Translations(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	service.SetLocaleResolver(fixedLocale("fr"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*product.Product")).Return(nil)
+
+	product, err := service.Create(context.Background(), CreateProductRequest{
+		StandID:          uuid.New(),
+		Name:             "Beer",
+		NameTranslations: i18n.Text{"FR": "Bière", "en": "Beer"},
+		Price:            350,
+		Category:         ProductCategoryBeer,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bière", product.Name, "the default locale's translation is the name")
+	assert.Equal(t, i18n.Text{"fr": "Bière", "en": "Beer"}, product.NameTranslations)
+
+	_, err = service.Create(context.Background(), CreateProductRequest{
+		StandID:          uuid.New(),
+		Name:             "Beer",
+		NameTranslations: i18n.Text{"en": "Beer"},
+		Price:            350,
+		Category:         ProductCategoryBeer,
+	})
+	assert.ErrorIs(t, err, i18n.ErrMissingDefaultLocale)
+
+	mockRepo.On("GetByID", mock.Anything, product.ID).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product).Return(nil)
+	name := "Blonde"
+	product, err = service.Update(context.Background(), product.ID, UpdateProductRequest{Name: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, i18n.Text{"fr": "Blonde", "en": "Beer"}, product.NameTranslations)
+
+	resp := product.ToResponse(1, "tokens")
+	resp.Localize([]string{"en-GB"})
+	assert.Equal(t, "Beer", resp.Name)
+}
+
+// TestService_AllergenDeclaration tests the validation of allergens, dietary tags and nutrition
+func TestService_AllergenDeclaration(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*product.Product")).Return(nil)
+	ctx := context.Background()
+
+	product, err := service.Create(ctx, CreateProductRequest{
+		StandID:     uuid.New(),
+		Name:        "Falafel wrap",
+		Price:       900,
+		Category:    ProductCategoryFood,
+		Allergens:   []Allergen{"sesame", AllergenGluten, AllergenSesame},
+		DietaryTags: []DietaryTag{DietaryVegan, DietaryHalal},
+		Nutrition:   &Nutrition{Basis: NutritionPer100g, EnergyKcal: 220, Fat: 9, SaturatedFat: 1.2, Carbohydrates: 27, Sugars: 3, Protein: 7, Salt: 1.1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Allergen{AllergenGluten, AllergenSesame}, product.Allergens, "normalized in Annex II order")
+	assert.Equal(t, []DietaryTag{DietaryVegan, DietaryHalal}, product.DietaryTags)
+	assert.NotNil(t, product.AllergensDeclaredAt)
+	assert.True(t, product.ToResponse(1, "tokens").AllergensDeclared)
+
+	undeclared, err := service.Create(ctx, CreateProductRequest{StandID: uuid.New(), Name: "Fries", Price: 400, Category: ProductCategoryFood})
+	assert.NoError(t, err)
+	assert.Nil(t, undeclared.AllergensDeclaredAt)
+	assert.Equal(t, []Allergen{}, undeclared.Allergens)
+	assert.False(t, undeclared.ToResponse(1, "tokens").AllergensDeclared, "no allergens is not the same as none declared")
+
+	for name, req := range map[string]CreateProductRequest{
+		"unknown allergen":        {Allergens: []Allergen{"PEPPER"}},
+		"unknown dietary tag":     {Allergens: []Allergen{}, DietaryTags: []DietaryTag{"KETO"}},
+		"vegan with milk":         {Allergens: []Allergen{AllergenMilk}, DietaryTags: []DietaryTag{DietaryVegan}},
+		"vegetarian with fish":    {Allergens: []Allergen{AllergenFish}, DietaryTags: []DietaryTag{DietaryVegetarian}},
+		"gluten-free with gluten": {Allergens: []Allergen{AllergenGluten}, DietaryTags: []DietaryTag{DietaryGlutenFree}},
+		"tags without allergens":  {DietaryTags: []DietaryTag{DietaryHalal}},
+		"sugars over carbs":       {Nutrition: &Nutrition{Basis: NutritionPer100ml, Carbohydrates: 10, Sugars: 11}},
+		"negative nutrition":      {Nutrition: &Nutrition{Basis: NutritionPer100g, Salt: -1}},
+		"nutrition over 100 g":    {Nutrition: &Nutrition{Basis: NutritionPer100g, Fat: 60, Carbohydrates: 50}},
+	} {
+		req.StandID, req.Name, req.Price, req.Category = uuid.New(), "Dish", 800, ProductCategoryFood
+		_, err := service.Create(ctx, req)
+		assert.ErrorIs(t, err, ErrInvalidDeclaration, name)
+	}
+
+	// Updates keep the declaration unless replaced, and check the merged result
+	mockRepo.On("GetByID", mock.Anything, product.ID).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product).Return(nil)
+	_, err = service.Update(ctx, product.ID, UpdateProductRequest{Allergens: []Allergen{AllergenEggs}})
+	assert.ErrorIs(t, err, ErrInvalidDeclaration, "the product is still tagged vegan")
+
+	product, err = service.Update(ctx, product.ID, UpdateProductRequest{
+		Allergens:   []Allergen{AllergenEggs, AllergenGluten},
+		DietaryTags: []DietaryTag{DietaryVegetarian},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Allergen{AllergenGluten, AllergenEggs}, product.Allergens)
+	assert.Equal(t, []DietaryTag{DietaryVegetarian}, product.DietaryTags)
+	assert.NotNil(t, product.Nutrition, "the nutrition is kept")
+}
+
+// TestMenuFilter tests the parsing and matching of the menu filters
+func TestMenuFilter(t *testing.T) {
+	filter, err := ParseMenuFilter("gluten, MILK", "vegan")
+	assert.NoError(t, err)
+	assert.Equal(t, MenuFilter{FreeOf: []Allergen{AllergenGluten, AllergenMilk}, Dietary: []DietaryTag{DietaryVegan}}, filter)
+
+	_, err = ParseMenuFilter("GLUTEN,PEPPER", "")
+	assert.ErrorIs(t, err, ErrInvalidDeclaration)
+	_, err = ParseMenuFilter("", "KETO")
+	assert.ErrorIs(t, err, ErrInvalidDeclaration)
+
+	empty, err := ParseMenuFilter("", "")
+	assert.NoError(t, err)
+	assert.True(t, empty.IsZero())
+
+	declared := time.Now()
+	salad := Product{Name: "Salad", Allergens: []Allergen{AllergenMustard}, AllergensDeclaredAt: &declared, DietaryTags: []DietaryTag{DietaryVegan}}
+	pizza := Product{Name: "Pizza", Allergens: []Allergen{AllergenGluten, AllergenMilk}, AllergensDeclaredAt: &declared, DietaryTags: []DietaryTag{DietaryVegetarian}}
+	cider := Product{Name: "Cider", Allergens: []Allergen{}, AllergensDeclaredAt: &declared, DietaryTags: []DietaryTag{DietaryVegan, DietaryGlutenFree}}
+	fries := Product{Name: "Fries", Allergens: []Allergen{}}
+	products := []Product{salad, pizza, cider, fries}
+
+	names := func(products []Product) []string {
+		var names []string
+		for _, p := range products {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Salad", "Cider"}, names(filterProducts(products, MenuFilter{FreeOf: []Allergen{AllergenGluten}})),
+		"products without a declaration are never free of an allergen")
+	assert.Equal(t, []string{"Cider"}, names(filterProducts(products, MenuFilter{Dietary: []DietaryTag{DietaryVegan, DietaryGlutenFree}})))
+	assert.Len(t, filterProducts(products, MenuFilter{}), 4)
+}
+
+// TestService_AllergenReport tests the compliance report of the allergen declarations
+func TestService_AllergenReport(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	festivalID := uuid.New()
+	missing := []UndeclaredProduct{{ProductID: uuid.New(), Name: "Fries", StandName: "Frituur", Category: ProductCategoryFood, Status: ProductStatusActive}}
+	mockRepo.On("CountDeclarations", mock.Anything, festivalID, DeclarationCategories).Return(int64(12), int64(11), nil)
+	mockRepo.On("ListUndeclared", mock.Anything, festivalID, DeclarationCategories).Return(missing, nil)
+
+	report, err := service.AllergenReport(context.Background(), festivalID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), report.Required)
+	assert.Equal(t, int64(11), report.Declared)
+	assert.False(t, report.Compliant)
+	assert.Equal(t, missing, report.Missing)
+
+	assert.True(t, ProductCategorySnack.RequiresDeclaration())
+	assert.False(t, ProductCategoryMerch.RequiresDeclaration())
+}
+
+// TestAdjustPrice tests bulk price update adjustments
+func TestAdjustPrice(t *testing.T) {
+	tests := []struct {
+		name           string
+		price          int64
+		adjustmentType PriceAdjustmentType
+		value          int64
+		want           int64
+		wantErr        error
+	}{
+		{name: "fixed amount increase", price: 450, adjustmentType: PriceAdjustmentFixedAmount, value: 50, want: 500},
+		{name: "fixed amount decrease", price: 450, adjustmentType: PriceAdjustmentFixedAmount, value: -50, want: 400},
+		{name: "percentage increase rounds to cent", price: 333, adjustmentType: PriceAdjustmentPercentage, value: 10, want: 366},
+		{name: "percentage decrease", price: 1000, adjustmentType: PriceAdjustmentPercentage, value: -25, want: 750},
+		{name: "set price", price: 450, adjustmentType: PriceAdjustmentSetPrice, value: 600, want: 600},
+		{name: "negative result rejected", price: 100, adjustmentType: PriceAdjustmentFixedAmount, value: -150, wantErr: ErrPriceUpdateNegative},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AdjustPrice(tt.price, tt.adjustmentType, tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInsufficientStock is returned when the stock left, minus the stock held for
+// pending orders, cannot cover an order
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+type ReservationStatus string
+
+const (
+	ReservationStatusHeld      ReservationStatus = "HELD"      // Counted against the stock until it expires
+	ReservationStatusConverted ReservationStatus = "CONVERTED" // Taken from the stock when the order was paid
+	ReservationStatusReleased  ReservationStatus = "RELEASED"  // Given back when the order was cancelled
+)
+
+// StockReservation holds stock of a limited product for a pending order, so it cannot
+// be sold twice between the order creation and its payment. An expired hold stops
+// counting against the stock without being updated.
+type StockReservation struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID   uuid.UUID         `json:"orderId" gorm:"type:uuid;not null;index"`
+	ProductID uuid.UUID         `json:"productId" gorm:"type:uuid;not null"`
+	Quantity  int               `json:"quantity" gorm:"not null"`
+	Status    ReservationStatus `json:"status" gorm:"not null;default:'HELD'"`
+	ExpiresAt time.Time         `json:"expiresAt" gorm:"not null"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
+
+// StockHold is a quantity of a product to hold for an order
+type StockHold struct {
+	ProductID uuid.UUID
+	Quantity  int
+}
+
+// checkAvailability checks the locked products can cover the holds, given the quantities
+// already held for other orders. Products with unlimited stock are left out of the
+// returned holds, as there is nothing to reserve.
+func checkAvailability(products []Product, held map[uuid.UUID]int, holds []StockHold) ([]StockHold, error) {
+	productMap := make(map[uuid.UUID]*Product, len(products))
+	for i := range products {
+		productMap[products[i].ID] = &products[i]
+	}
+
+	// An order may list the same product more than once
+	quantities := make(map[uuid.UUID]int, len(holds))
+	order := make([]uuid.UUID, 0, len(holds))
+	for _, hold := range holds {
+		if _, seen := quantities[hold.ProductID]; !seen {
+			order = append(order, hold.ProductID)
+		}
+		quantities[hold.ProductID] += hold.Quantity
+	}
+
+	limited := make([]StockHold, 0, len(order))
+	for _, productID := range order {
+		product, exists := productMap[productID]
+		if !exists || product.Stock == nil {
+			continue
+		}
+		if *product.Stock-held[productID] < quantities[productID] {
+			return nil, fmt.Errorf("%w for product %s", ErrInsufficientStock, product.Name)
+		}
+		limited = append(limited, StockHold{ProductID: productID, Quantity: quantities[productID]})
+	}
+	return limited, nil
+}
@@ -0,0 +1,119 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockReservationRepository holds the stock of pending orders. Holds are checked and
+// taken with the products locked, so concurrent orders cannot oversell them.
+type StockReservationRepository interface {
+	// Reserve holds the stock of an order until expiresAt, or fails with
+	// ErrInsufficientStock
+	Reserve(ctx context.Context, orderID uuid.UUID, holds []StockHold, now, expiresAt time.Time) error
+	// Convert takes the stock of a paid order and closes its holds
+	Convert(ctx context.Context, orderID uuid.UUID, updates []StockUpdate) error
+	// Release gives back the stock held for an order
+	Release(ctx context.Context, orderID uuid.UUID) error
+}
+
+type stockReservationRepository struct {
+	db *gorm.DB
+}
+
+func NewStockReservationRepository(db *gorm.DB) StockReservationRepository {
+	return &stockReservationRepository{db: db}
+}
+
+func (r *stockReservationRepository) Reserve(ctx context.Context, orderID uuid.UUID, holds []StockHold, now, expiresAt time.Time) error {
+	if len(holds) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(holds))
+	for i, hold := range holds {
+		ids[i] = hold.ProductID
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock in a stable order so concurrent orders for the same products cannot deadlock
+		var products []Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to lock products: %w", err)
+		}
+
+		var rows []struct {
+			ProductID uuid.UUID
+			Held      int
+		}
+		if err := tx.Model(&StockReservation{}).
+			Select("product_id, COALESCE(SUM(quantity), 0) as held").
+			Where("product_id IN ? AND status = ? AND expires_at > ?", ids, ReservationStatusHeld, now).
+			Group("product_id").
+			Scan(&rows).Error; err != nil {
+			return fmt.Errorf("failed to get held stock: %w", err)
+		}
+		held := make(map[uuid.UUID]int, len(rows))
+		for _, row := range rows {
+			held[row.ProductID] = row.Held
+		}
+
+		limited, err := checkAvailability(products, held, holds)
+		if err != nil {
+			return err
+		}
+		if len(limited) == 0 {
+			return nil
+		}
+
+		reservations := make([]StockReservation, len(limited))
+		for i, hold := range limited {
+			reservations[i] = StockReservation{
+				OrderID:   orderID,
+				ProductID: hold.ProductID,
+				Quantity:  hold.Quantity,
+				Status:    ReservationStatusHeld,
+				ExpiresAt: expiresAt,
+			}
+		}
+		if err := tx.Create(&reservations).Error; err != nil {
+			return fmt.Errorf("failed to create stock reservations: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *stockReservationRepository) Convert(ctx context.Context, orderID uuid.UUID, updates []StockUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := applyStockUpdates(tx, updates); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&StockReservation{}).
+			Where("order_id = ? AND status = ?", orderID, ReservationStatusHeld).
+			Update("status", ReservationStatusConverted).Error; err != nil {
+			return fmt.Errorf("failed to convert stock reservations: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *stockReservationRepository) Release(ctx context.Context, orderID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).
+		Model(&StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, ReservationStatusHeld).
+		Update("status", ReservationStatusReleased).Error; err != nil {
+		return fmt.Errorf("failed to release stock reservations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckAvailability tests holds are checked against the stock left after the
+// other orders' holds
+func TestCheckAvailability(t *testing.T) {
+	stock := func(n int) *int { return &n }
+	beer := Product{ID: uuid.New(), Name: "Beer", Stock: stock(10)}
+	fries := Product{ID: uuid.New(), Name: "Fries", Stock: nil}
+	products := []Product{beer, fries}
+
+	limited, err := checkAvailability(products, map[uuid.UUID]int{beer.ID: 6}, []StockHold{
+		{ProductID: beer.ID, Quantity: 3},
+		{ProductID: fries.ID, Quantity: 50},
+		{ProductID: beer.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []StockHold{{ProductID: beer.ID, Quantity: 4}}, limited)
+
+	_, err = checkAvailability(products, map[uuid.UUID]int{beer.ID: 7}, []StockHold{
+		{ProductID: beer.ID, Quantity: 2},
+		{ProductID: beer.ID, Quantity: 2},
+	})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assert.Contains(t, err.Error(), "Beer")
+
+	// Unlimited products are never held
+	limited, err = checkAvailability(products, nil, []StockHold{{ProductID: fries.ID, Quantity: 1000}})
+	require.NoError(t, err)
+	assert.Empty(t, limited)
+}
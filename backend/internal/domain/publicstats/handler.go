@@ -0,0 +1,171 @@
+package publicstats
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped public stats settings, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	stats := r.Group("/public-stats")
+	{
+		stats.GET("/settings", h.GetSettings)
+		stats.PATCH("/settings", h.UpdateSettings)
+		stats.GET("/preview", h.Preview)
+	}
+}
+
+// RegisterPublicRoutes registers the unauthenticated stats route embedded on festival websites
+func (h *Handler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET("/festivals/:id/public-stats", h.GetPublic)
+}
+
+// GetSettings returns the public stats settings
+// @Summary Get public stats settings
+// @Description Get whether the public stats are enabled and which metrics they publish
+// @Tags public-stats
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Settings} "Public stats settings"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/public-stats/settings [get]
+func (h *Handler) GetSettings(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	settings, err := h.service.GetSettings(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, settings)
+}
+
+// UpdateSettings updates the public stats settings
+// @Summary Update public stats settings
+// @Description Enable or disable the public stats, choose the published metrics and the length of the product and category rankings. The cached stats are dropped.
+// @Tags public-stats
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body UpdateSettingsRequest true "Settings changes"
+// @Success 200 {object} response.Response{data=Settings} "Settings updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid metric or ranking length"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/public-stats/settings [patch]
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(c.Request.Context(), festivalID, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, settings)
+}
+
+// Preview returns the stats the settings would publish
+// @Summary Preview public stats
+// @Description Compute the anonymized stats selected by the settings, even while they are disabled
+// @Tags public-stats
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=PublicStats} "Stats preview"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/public-stats/preview [get]
+func (h *Handler) Preview(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	stats, err := h.service.Preview(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, stats)
+}
+
+// GetPublic returns the published stats of a festival
+// @Summary Get festival public stats
+// @Description Get the anonymized aggregates an organizer chose to publish, by festival ID or slug (no auth required). Counts are rounded down to two significant digits and left out below 10.
+// @Tags public-stats
+// @Produce json
+// @Param id path string true "Festival ID or slug"
+// @Success 200 {object} response.Response{data=PublicStats} "Public stats"
+// @Failure 404 {object} response.ErrorResponse "Festival not found or stats not published"
+// @Router /festivals/{id}/public-stats [get]
+func (h *Handler) GetPublic(c *gin.Context) {
+	stats, err := h.service.GetPublic(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=600")
+	response.OK(c, stats)
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrStatsDisabled):
+		// Same answer as an unknown festival, so that the endpoint does not reveal
+		// which festivals keep their stats private
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrInvalidMetric):
+		response.BadRequest(c, "INVALID_METRIC", err.Error(), nil)
+	case errors.Is(err, ErrInvalidTopLimit):
+		response.BadRequest(c, "INVALID_TOP_LIMIT", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,114 @@
+package publicstats
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Public stats errors
+var (
+	ErrFestivalNotFound = errors.New("festival not found")
+	ErrStatsDisabled    = errors.New("public stats are not enabled for this festival")
+	ErrInvalidMetric    = errors.New("unknown public stats metric")
+	ErrInvalidTopLimit  = errors.New("topLimit must be between 1 and 10")
+)
+
+const (
+	// MinCount is the smallest count ever published; smaller counts could single out
+	// a handful of attendees and are left out
+	MinCount = 10
+	// DefaultTopLimit is how many products and categories are ranked by default
+	DefaultTopLimit = 5
+	// MaxTopLimit is the longest ranking an organizer may publish
+	MaxTopLimit = 10
+)
+
+// Metric is an aggregate an organizer may publish
+type Metric string
+
+const (
+	MetricTicketsSold   Metric = "TICKETS_SOLD"   // Tickets sold, cancelled ones excluded
+	MetricAttendees     Metric = "ATTENDEES"      // Tickets checked in at the gates
+	MetricOrders        Metric = "ORDERS"         // Paid orders at the stands
+	MetricItemsSold     Metric = "ITEMS_SOLD"     // Units sold in paid orders
+	MetricWallets       Metric = "WALLETS"        // Cashless wallets opened
+	MetricTopProducts   Metric = "TOP_PRODUCTS"   // Best selling products by units sold
+	MetricTopCategories Metric = "TOP_CATEGORIES" // Best selling product categories by units sold
+)
+
+func (m Metric) IsValid() bool {
+	switch m {
+	case MetricTicketsSold, MetricAttendees, MetricOrders, MetricItemsSold, MetricWallets,
+		MetricTopProducts, MetricTopCategories:
+		return true
+	}
+	return false
+}
+
+// Settings is the organizer's choice of what the public stats endpoint exposes. The
+// endpoint answers 404 until the stats are enabled.
+type Settings struct {
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;primary_key"`
+	Enabled    bool       `json:"enabled"`
+	Metrics    []Metric   `json:"metrics" gorm:"type:jsonb;serializer:json"`
+	TopLimit   int        `json:"topLimit" gorm:"default:5"`
+	UpdatedBy  *uuid.UUID `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (Settings) TableName() string {
+	return "public_stats_settings"
+}
+
+// Has reports whether a metric is published
+func (s *Settings) Has(metric Metric) bool {
+	return contains(s.Metrics, metric)
+}
+
+// UpdateSettingsRequest changes the public stats settings; omitted fields are kept
+type UpdateSettingsRequest struct {
+	Enabled  *bool     `json:"enabled,omitempty"`
+	Metrics  *[]Metric `json:"metrics,omitempty"`
+	TopLimit *int      `json:"topLimit,omitempty"`
+}
+
+// FestivalInfo identifies the festival the stats are published for
+type FestivalInfo struct {
+	ID   uuid.UUID
+	Name string
+	Slug string
+}
+
+// Totals are the raw counts of a festival, before anonymization
+type Totals struct {
+	TicketsSold int64
+	Attendees   int64
+	Orders      int64
+	ItemsSold   int64
+	Wallets     int64
+}
+
+// Entry is a ranked product or category with the units sold
+type Entry struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// PublicStats are the anonymized aggregates published for a festival. Counts are
+// rounded down to two significant digits and omitted below MinCount; no amount of
+// money and nothing about a single attendee, wallet or stand is ever exposed.
+type PublicStats struct {
+	FestivalID    uuid.UUID `json:"festivalId"`
+	FestivalName  string    `json:"festivalName"`
+	TicketsSold   *int64    `json:"ticketsSold,omitempty"`
+	Attendees     *int64    `json:"attendees,omitempty"`
+	Orders        *int64    `json:"orders,omitempty"`
+	ItemsSold     *int64    `json:"itemsSold,omitempty"`
+	Wallets       *int64    `json:"wallets,omitempty"`
+	TopProducts   []Entry   `json:"topProducts,omitempty"`
+	TopCategories []Entry   `json:"topCategories,omitempty"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+}
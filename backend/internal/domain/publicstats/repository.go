@@ -0,0 +1,150 @@
+package publicstats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	GetSettings(ctx context.Context, festivalID uuid.UUID) (*Settings, error)
+	SaveSettings(ctx context.Context, settings *Settings) error
+	GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error)
+	GetFestivalBySlug(ctx context.Context, slug string) (*FestivalInfo, error)
+	// GetTotals counts the tickets, check-ins, paid orders, units sold and wallets of a festival
+	GetTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error)
+	// TopProducts ranks the products by units sold in paid orders, merging the products
+	// of the same name sold at several stands, and leaves out those under minCount
+	TopProducts(ctx context.Context, festivalID uuid.UUID, minCount int64, limit int) ([]Entry, error)
+	// TopCategories ranks the product categories by units sold in paid orders and leaves
+	// out those under minCount
+	TopCategories(ctx context.Context, festivalID uuid.UUID, minCount int64, limit int) ([]Entry, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetSettings(ctx context.Context, festivalID uuid.UUID) (*Settings, error) {
+	var settings Settings
+	err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).First(&settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get public stats settings: %w", err)
+	}
+	return &settings, nil
+}
+
+func (r *repository) SaveSettings(ctx context.Context, settings *Settings) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "festival_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "metrics", "top_limit", "updated_by", "updated_at"}),
+	}).Create(settings).Error
+	if err != nil {
+		return fmt.Errorf("failed to save public stats settings: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	return r.getFestival(ctx, "id = ?", festivalID)
+}
+
+func (r *repository) GetFestivalBySlug(ctx context.Context, slug string) (*FestivalInfo, error) {
+	return r.getFestival(ctx, "slug = ?", slug)
+}
+
+func (r *repository) getFestival(ctx context.Context, query string, arg interface{}) (*FestivalInfo, error) {
+	var festivals []FestivalInfo
+	err := r.db.WithContext(ctx).
+		Table("public.festivals").
+		Select("id, name, slug").
+		Where(query, arg).
+		Limit(1).
+		Scan(&festivals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(festivals) == 0 {
+		return nil, nil
+	}
+	return &festivals[0], nil
+}
+
+func (r *repository) GetTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error) {
+	var totals Totals
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM public.tickets
+				WHERE festival_id = @festival AND status NOT IN ('CANCELLED', 'TRANSFERRED')) as tickets_sold,
+			(SELECT COUNT(*) FROM public.tickets
+				WHERE festival_id = @festival AND checked_in_at IS NOT NULL) as attendees,
+			(SELECT COUNT(*) FROM public.orders
+				WHERE festival_id = @festival AND status = 'PAID') as orders,
+			(SELECT COALESCE(SUM((item->>'quantity')::int), 0)
+				FROM public.orders o
+				CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+				WHERE o.festival_id = @festival AND o.status = 'PAID') as items_sold,
+			(SELECT COUNT(*) FROM public.wallets
+				WHERE festival_id = @festival) as wallets`,
+		map[string]interface{}{"festival": festivalID},
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public stats totals: %w", err)
+	}
+	return &totals, nil
+}
+
+func (r *repository) TopProducts(ctx context.Context, festivalID uuid.UUID, minCount int64, limit int) ([]Entry, error) {
+	var entries []Entry
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			MAX(item->>'productName') as name,
+			SUM((item->>'quantity')::int) as count
+		FROM public.orders o
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		WHERE o.festival_id = ? AND o.status = 'PAID'
+		GROUP BY LOWER(TRIM(item->>'productName'))
+		HAVING SUM((item->>'quantity')::int) >= ?
+		ORDER BY count DESC, name
+		LIMIT ?`,
+		festivalID, minCount, limit,
+	).Scan(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *repository) TopCategories(ctx context.Context, festivalID uuid.UUID, minCount int64, limit int) ([]Entry, error) {
+	var entries []Entry
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			c.name,
+			SUM((item->>'quantity')::int) as count
+		FROM public.orders o
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		INNER JOIN public.products p ON p.id = (item->>'productId')::uuid
+		INNER JOIN public.categories c ON c.id = p.category_id
+		WHERE o.festival_id = ? AND o.status = 'PAID'
+		GROUP BY c.id, c.name
+		HAVING SUM((item->>'quantity')::int) >= ?
+		ORDER BY count DESC, c.name
+		LIMIT ?`,
+		festivalID, minCount, limit,
+	).Scan(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top categories: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,288 @@
+package publicstats
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// publicStatsTTL is how long the published stats are served from the cache; the
+// counts move slowly enough that the festival website never needs them fresher
+const publicStatsTTL = 10 * time.Minute
+
+// defaultMetrics are published when an organizer enables the stats without choosing
+var defaultMetrics = []Metric{MetricTicketsSold, MetricItemsSold, MetricTopProducts}
+
+type Service struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	now         func() time.Time
+}
+
+// NewService creates a new public stats service; redisClient may be nil to disable caching
+func NewService(repo Repository, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+		now:         time.Now,
+	}
+}
+
+// GetSettings returns the public stats settings of a festival, disabled when they were
+// never configured
+func (s *Service) GetSettings(ctx context.Context, festivalID uuid.UUID) (*Settings, error) {
+	settings, err := s.repo.GetSettings(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return &Settings{
+			FestivalID: festivalID,
+			Metrics:    append([]Metric(nil), defaultMetrics...),
+			TopLimit:   DefaultTopLimit,
+		}, nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings validates and applies a settings update, dropping the cached stats
+func (s *Service) UpdateSettings(ctx context.Context, festivalID uuid.UUID, updatedBy *uuid.UUID, req UpdateSettingsRequest) (*Settings, error) {
+	settings, err := s.GetSettings(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Metrics != nil {
+		metrics := make([]Metric, 0, len(*req.Metrics))
+		for _, metric := range *req.Metrics {
+			if !metric.IsValid() {
+				return nil, ErrInvalidMetric
+			}
+			if !contains(metrics, metric) {
+				metrics = append(metrics, metric)
+			}
+		}
+		settings.Metrics = metrics
+	}
+	if req.TopLimit != nil {
+		if *req.TopLimit < 1 || *req.TopLimit > MaxTopLimit {
+			return nil, ErrInvalidTopLimit
+		}
+		settings.TopLimit = *req.TopLimit
+	}
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+
+	now := s.now()
+	if settings.CreatedAt.IsZero() {
+		settings.CreatedAt = now
+	}
+	settings.UpdatedAt = now
+	settings.UpdatedBy = updatedBy
+
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	s.Invalidate(ctx, festivalID)
+	return settings, nil
+}
+
+// Preview computes the stats the settings publish, enabled or not, so that organizers
+// can check them before going public
+func (s *Service) Preview(ctx context.Context, festivalID uuid.UUID) (*PublicStats, error) {
+	festival, err := s.repo.GetFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	settings, err := s.GetSettings(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return s.compute(ctx, festival, settings)
+}
+
+// GetPublic returns the published stats of a festival looked up by ID or slug
+func (s *Service) GetPublic(ctx context.Context, idOrSlug string) (*PublicStats, error) {
+	key := s.keyBuilder.FestivalPublicStatsKey(idOrSlug)
+	if stats := s.cached(ctx, key); stats != nil {
+		return stats, nil
+	}
+
+	var festival *FestivalInfo
+	var err error
+	if id, parseErr := uuid.Parse(idOrSlug); parseErr == nil {
+		festival, err = s.repo.GetFestival(ctx, id)
+	} else {
+		festival, err = s.repo.GetFestivalBySlug(ctx, idOrSlug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+
+	settings, err := s.repo.GetSettings(ctx, festival.ID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil || !settings.Enabled {
+		return nil, ErrStatsDisabled
+	}
+
+	stats, err := s.compute(ctx, festival, settings)
+	if err != nil {
+		return nil, err
+	}
+	s.cache(ctx, key, stats)
+	return stats, nil
+}
+
+// compute gathers and anonymizes the metrics selected by the settings
+func (s *Service) compute(ctx context.Context, festival *FestivalInfo, settings *Settings) (*PublicStats, error) {
+	stats := &PublicStats{
+		FestivalID:   festival.ID,
+		FestivalName: festival.Name,
+		GeneratedAt:  s.now().UTC(),
+	}
+
+	if settings.Has(MetricTicketsSold) || settings.Has(MetricAttendees) || settings.Has(MetricOrders) ||
+		settings.Has(MetricItemsSold) || settings.Has(MetricWallets) {
+		totals, err := s.repo.GetTotals(ctx, festival.ID)
+		if err != nil {
+			return nil, err
+		}
+		if settings.Has(MetricTicketsSold) {
+			stats.TicketsSold = Anonymize(totals.TicketsSold)
+		}
+		if settings.Has(MetricAttendees) {
+			stats.Attendees = Anonymize(totals.Attendees)
+		}
+		if settings.Has(MetricOrders) {
+			stats.Orders = Anonymize(totals.Orders)
+		}
+		if settings.Has(MetricItemsSold) {
+			stats.ItemsSold = Anonymize(totals.ItemsSold)
+		}
+		if settings.Has(MetricWallets) {
+			stats.Wallets = Anonymize(totals.Wallets)
+		}
+	}
+
+	limit := settings.TopLimit
+	if limit < 1 || limit > MaxTopLimit {
+		limit = DefaultTopLimit
+	}
+	if settings.Has(MetricTopProducts) {
+		products, err := s.repo.TopProducts(ctx, festival.ID, MinCount, limit)
+		if err != nil {
+			return nil, err
+		}
+		stats.TopProducts = anonymizeEntries(products)
+	}
+	if settings.Has(MetricTopCategories) {
+		categories, err := s.repo.TopCategories(ctx, festival.ID, MinCount, limit)
+		if err != nil {
+			return nil, err
+		}
+		stats.TopCategories = anonymizeEntries(categories)
+	}
+
+	return stats, nil
+}
+
+// Anonymize rounds a count down to two significant digits, e.g. 123456 to 120000, and
+// returns nil below MinCount
+func Anonymize(count int64) *int64 {
+	if count < MinCount {
+		return nil
+	}
+	unit := int64(1)
+	for count/unit >= 100 {
+		unit *= 10
+	}
+	rounded := count / unit * unit
+	return &rounded
+}
+
+func anonymizeEntries(entries []Entry) []Entry {
+	anonymized := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		count := Anonymize(entry.Count)
+		if count == nil {
+			continue
+		}
+		anonymized = append(anonymized, Entry{Name: entry.Name, Count: *count})
+	}
+	return anonymized
+}
+
+// Invalidate drops the cached stats of the festival under its ID and slug; they are
+// computed again on the next request
+func (s *Service) Invalidate(ctx context.Context, festivalID uuid.UUID) {
+	if s.redisClient == nil {
+		return
+	}
+
+	keys := []string{s.keyBuilder.FestivalPublicStatsKey(festivalID.String())}
+	if festival, err := s.repo.GetFestival(ctx, festivalID); err == nil && festival != nil {
+		keys = append(keys, s.keyBuilder.FestivalPublicStatsKey(festival.Slug))
+	}
+
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to invalidate public stats cache")
+	}
+}
+
+func (s *Service) cached(ctx context.Context, key string) *PublicStats {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	data, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil
+	}
+
+	var stats PublicStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil
+	}
+	return &stats
+}
+
+func (s *Service) cache(ctx context.Context, key string, stats *PublicStats) {
+	if s.redisClient == nil {
+		return
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+	if err := s.redisClient.Set(ctx, key, data, publicStatsTTL).Err(); err != nil {
+		log.Warn().Err(err).Msg("Failed to cache public stats")
+	}
+}
+
+func contains(metrics []Metric, metric Metric) bool {
+	for _, m := range metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package publicstats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	festival   *FestivalInfo
+	settings   *Settings
+	totals     Totals
+	products   []Entry
+	categories []Entry
+	totalsRead bool
+}
+
+func (r *fakeRepository) GetSettings(ctx context.Context, festivalID uuid.UUID) (*Settings, error) {
+	return r.settings, nil
+}
+
+func (r *fakeRepository) SaveSettings(ctx context.Context, settings *Settings) error {
+	r.settings = settings
+	return nil
+}
+
+func (r *fakeRepository) GetFestival(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	if r.festival == nil || r.festival.ID != festivalID {
+		return nil, nil
+	}
+	return r.festival, nil
+}
+
+func (r *fakeRepository) GetFestivalBySlug(ctx context.Context, slug string) (*FestivalInfo, error) {
+	if r.festival == nil || r.festival.Slug != slug {
+		return nil, nil
+	}
+	return r.festival, nil
+}
+
+func (r *fakeRepository) GetTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error) {
+	r.totalsRead = true
+	totals := r.totals
+	return &totals, nil
+}
+
+func (r *fakeRepository) TopProducts(ctx context.Context, festivalID uuid.UUID, minCount int64, limit int) ([]Entry, error) {
+	return r.products, nil
+}
+
+func (r *fakeRepository) TopCategories(ctx context.Context, festivalID uuid.UUID, minCount int64, limit int) ([]Entry, error) {
+	return r.categories, nil
+}
+
+func newFestival() *FestivalInfo {
+	return &FestivalInfo{ID: uuid.New(), Name: "Summer Fest", Slug: "summer-fest"}
+}
+
+func TestAnonymize(t *testing.T) {
+	tests := []struct {
+		count    int64
+		expected *int64
+	}{
+		{0, nil},
+		{9, nil},
+		{10, int64Ptr(10)},
+		{57, int64Ptr(57)},
+		{999, int64Ptr(990)},
+		{123456, int64Ptr(120000)},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Anonymize(tt.count), "count %d", tt.count)
+	}
+}
+
+func TestService_GetPublic_Disabled(t *testing.T) {
+	festival := newFestival()
+	repo := &fakeRepository{festival: festival}
+	service := NewService(repo, nil)
+
+	_, err := service.GetPublic(context.Background(), festival.Slug)
+	assert.ErrorIs(t, err, ErrStatsDisabled)
+
+	repo.settings = &Settings{FestivalID: festival.ID, Enabled: false, Metrics: []Metric{MetricItemsSold}}
+	_, err = service.GetPublic(context.Background(), festival.ID.String())
+	assert.ErrorIs(t, err, ErrStatsDisabled)
+
+	_, err = service.GetPublic(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrFestivalNotFound)
+}
+
+func TestService_GetPublic_SelectedMetricsOnly(t *testing.T) {
+	festival := newFestival()
+	repo := &fakeRepository{
+		festival: festival,
+		settings: &Settings{
+			FestivalID: festival.ID,
+			Enabled:    true,
+			Metrics:    []Metric{MetricItemsSold, MetricTopProducts},
+			TopLimit:   3,
+		},
+		totals:   Totals{TicketsSold: 25000, ItemsSold: 123456, Wallets: 5},
+		products: []Entry{{Name: "Beer", Count: 45678}, {Name: "Fries", Count: 8}},
+	}
+	service := NewService(repo, nil)
+
+	stats, err := service.GetPublic(context.Background(), festival.Slug)
+	require.NoError(t, err)
+
+	assert.Equal(t, festival.Name, stats.FestivalName)
+	require.NotNil(t, stats.ItemsSold)
+	assert.Equal(t, int64(120000), *stats.ItemsSold)
+	assert.Nil(t, stats.TicketsSold, "unselected metrics are not published")
+	assert.Nil(t, stats.Wallets)
+	assert.Nil(t, stats.TopCategories)
+	assert.Equal(t, []Entry{{Name: "Beer", Count: 45000}}, stats.TopProducts, "small counts are left out")
+}
+
+func TestService_Preview_SkipsTotalsWhenUnused(t *testing.T) {
+	festival := newFestival()
+	repo := &fakeRepository{
+		festival:   festival,
+		settings:   &Settings{FestivalID: festival.ID, Metrics: []Metric{MetricTopCategories}},
+		categories: []Entry{{Name: "Drinks", Count: 1234}},
+	}
+	service := NewService(repo, nil)
+
+	stats, err := service.Preview(context.Background(), festival.ID)
+	require.NoError(t, err)
+
+	assert.False(t, repo.totalsRead)
+	assert.Equal(t, []Entry{{Name: "Drinks", Count: 1200}}, stats.TopCategories)
+}
+
+func TestService_UpdateSettings(t *testing.T) {
+	festival := newFestival()
+	repo := &fakeRepository{festival: festival}
+	service := NewService(repo, nil)
+	ctx := context.Background()
+
+	settings, err := service.GetSettings(ctx, festival.ID)
+	require.NoError(t, err)
+	assert.False(t, settings.Enabled)
+	assert.Equal(t, DefaultTopLimit, settings.TopLimit)
+
+	enabled := true
+	metrics := []Metric{MetricOrders, MetricOrders, MetricAttendees}
+	settings, err = service.UpdateSettings(ctx, festival.ID, nil, UpdateSettingsRequest{Enabled: &enabled, Metrics: &metrics})
+	require.NoError(t, err)
+	assert.True(t, settings.Enabled)
+	assert.Equal(t, []Metric{MetricOrders, MetricAttendees}, settings.Metrics)
+	assert.Equal(t, settings, repo.settings)
+
+	invalid := []Metric{"REVENUE"}
+	_, err = service.UpdateSettings(ctx, festival.ID, nil, UpdateSettingsRequest{Metrics: &invalid})
+	assert.ErrorIs(t, err, ErrInvalidMetric)
+
+	limit := MaxTopLimit + 1
+	_, err = service.UpdateSettings(ctx, festival.ID, nil, UpdateSettingsRequest{TopLimit: &limit})
+	assert.ErrorIs(t, err, ErrInvalidTopLimit)
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,149 @@
+package realtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// JobStatus is the lifecycle stage of a background job
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusStarted   JobStatus = "started"
+	JobStatusProgress  JobStatus = "progress"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job kinds shown in the jobs panel of the dashboard
+const (
+	JobKindReport         = "report"
+	JobKindReconciliation = "reconciliation"
+	JobKindRecallRefunds  = "recall_refunds"
+	JobKindWalletBatch    = "wallet_batch"
+)
+
+// jobProgressStep is the smallest progress change, in percent, pushed to the dashboards
+const jobProgressStep = 5
+
+// Job represents a background job event for the jobs panel of the dashboard
+type Job struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Status    JobStatus `json:"status"`
+	Progress  int       `json:"progress"` // percent
+	ResultURL string    `json:"result_url,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JobBroadcaster pushes job events to the dashboards of a festival; satisfied by Service
+// and Publisher
+type JobBroadcaster interface {
+	BroadcastJob(ctx context.Context, festivalID string, job *Job)
+}
+
+// Publisher publishes job events from the worker, which has no WebSocket clients, to
+// the API instances through Redis
+type Publisher struct {
+	redis *redis.Client
+}
+
+// NewPublisher creates a publisher over the Redis client shared with the API
+func NewPublisher(redisClient *redis.Client) *Publisher {
+	return &Publisher{redis: redisClient}
+}
+
+// BroadcastJob publishes a job event; events are best effort and failures are logged
+func (p *Publisher) BroadcastJob(ctx context.Context, festivalID string, job *Job) {
+	if err := publish(ctx, p.redis, festivalID, "job", job); err != nil {
+		log.Warn().Err(err).
+			Str("festival_id", festivalID).
+			Str("job_id", job.ID).
+			Msg("Failed to publish job")
+	}
+}
+
+// JobTracker reports the lifecycle of one background job. A nil tracker, returned when
+// no broadcaster is set, reports nothing.
+type JobTracker struct {
+	broadcaster JobBroadcaster
+	festivalID  string
+	job         Job
+	now         func() time.Time
+}
+
+// TrackJob starts tracking a job of a festival, nil when broadcaster is nil
+func TrackJob(broadcaster JobBroadcaster, festivalID, id, kind, title string) *JobTracker {
+	if broadcaster == nil {
+		return nil
+	}
+	return &JobTracker{
+		broadcaster: broadcaster,
+		festivalID:  festivalID,
+		job:         Job{ID: id, Kind: kind, Title: title},
+		now:         time.Now,
+	}
+}
+
+// Queued reports the job waiting for a worker
+func (t *JobTracker) Queued(ctx context.Context) {
+	t.send(ctx, JobStatusQueued, 0)
+}
+
+// Started reports a worker picking up the job
+func (t *JobTracker) Started(ctx context.Context) {
+	t.send(ctx, JobStatusStarted, 0)
+}
+
+// Progress reports done of total items processed. Changes smaller than a few percent
+// are not pushed, so that large batches do not flood the dashboards.
+func (t *JobTracker) Progress(ctx context.Context, done, total int) {
+	if t == nil || total <= 0 {
+		return
+	}
+	progress := done * 100 / total
+	if progress > 100 {
+		progress = 100
+	}
+	if progress == t.job.Progress || (progress < t.job.Progress+jobProgressStep && progress < 100) {
+		return
+	}
+	t.send(ctx, JobStatusProgress, progress)
+}
+
+// Completed reports the job done, with a link to its result when it has one
+func (t *JobTracker) Completed(ctx context.Context, resultURL string) {
+	if t == nil {
+		return
+	}
+	t.job.ResultURL = resultURL
+	t.send(ctx, JobStatusCompleted, 100)
+}
+
+// Failed reports the job failed with the reason
+func (t *JobTracker) Failed(ctx context.Context, err error) {
+	if t == nil {
+		return
+	}
+	if err != nil {
+		t.job.Error = err.Error()
+	}
+	t.send(ctx, JobStatusFailed, t.job.Progress)
+}
+
+func (t *JobTracker) send(ctx context.Context, status JobStatus, progress int) {
+	if t == nil {
+		return
+	}
+	t.job.Status = status
+	t.job.Progress = progress
+	t.job.Timestamp = t.now()
+	job := t.job
+	t.broadcaster.BroadcastJob(ctx, t.festivalID, &job)
+}
@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeJobBroadcaster struct {
+	festivalIDs []string
+	jobs        []Job
+}
+
+func (b *fakeJobBroadcaster) BroadcastJob(ctx context.Context, festivalID string, job *Job) {
+	b.festivalIDs = append(b.festivalIDs, festivalID)
+	b.jobs = append(b.jobs, *job)
+}
+
+func TestJobTracker_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	broadcaster := &fakeJobBroadcaster{}
+
+	job := TrackJob(broadcaster, "festival-1", "job-1", JobKindRecallRefunds, "Recall of Lager")
+	job.Queued(ctx)
+	job.Started(ctx)
+	for i := 1; i <= 200; i++ {
+		job.Progress(ctx, i, 200)
+	}
+	job.Completed(ctx, "/api/v1/festivals/festival-1/recalls/job-1")
+
+	// Queued, started, 20 steps of 5% and completed
+	require.Len(t, broadcaster.jobs, 23)
+	assert.Equal(t, JobStatusQueued, broadcaster.jobs[0].Status)
+	assert.Equal(t, JobStatusStarted, broadcaster.jobs[1].Status)
+	assert.Equal(t, 5, broadcaster.jobs[2].Progress)
+	assert.Equal(t, 100, broadcaster.jobs[21].Progress)
+
+	completed := broadcaster.jobs[22]
+	assert.Equal(t, JobStatusCompleted, completed.Status)
+	assert.Equal(t, "job-1", completed.ID)
+	assert.Equal(t, JobKindRecallRefunds, completed.Kind)
+	assert.Equal(t, "/api/v1/festivals/festival-1/recalls/job-1", completed.ResultURL)
+	for _, festivalID := range broadcaster.festivalIDs {
+		assert.Equal(t, "festival-1", festivalID)
+	}
+}
+
+func TestJobTracker_Failed(t *testing.T) {
+	ctx := context.Background()
+	broadcaster := &fakeJobBroadcaster{}
+
+	job := TrackJob(broadcaster, "festival-1", "job-1", JobKindReport, "Sales report (csv)")
+	job.Started(ctx)
+	job.Progress(ctx, 1, 3)
+	job.Failed(ctx, errors.New("storage unavailable"))
+
+	require.Len(t, broadcaster.jobs, 3)
+	failed := broadcaster.jobs[2]
+	assert.Equal(t, JobStatusFailed, failed.Status)
+	assert.Equal(t, 33, failed.Progress)
+	assert.Equal(t, "storage unavailable", failed.Error)
+}
+
+func TestJobTracker_WithoutBroadcaster(t *testing.T) {
+	ctx := context.Background()
+
+	job := TrackJob(nil, "festival-1", "job-1", JobKindReconciliation, "Wallet reconciliation")
+	assert.Nil(t, job)
+
+	// A nil tracker reports nothing
+	job.Started(ctx)
+	job.Progress(ctx, 1, 2)
+	job.Completed(ctx, "")
+	job.Failed(ctx, errors.New("failed"))
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// updatesChannel is the Redis channel relaying updates to the clients of every instance
+const updatesChannel = "festival:updates"
+
 // StatsUpdate represents real-time stats data
 type StatsUpdate struct {
 	TotalRevenue         float64   `json:"total_revenue"`
@@ -191,7 +194,7 @@ func (s *Service) Stop() {
 // subscribeToRedisUpdates listens for updates from Redis pub/sub
 func (s *Service) subscribeToRedisUpdates() {
 	ctx := context.Background()
-	pubsub := s.redis.Subscribe(ctx, "festival:updates")
+	pubsub := s.redis.Subscribe(ctx, updatesChannel)
 	defer pubsub.Close()
 
 	ch := pubsub.Channel()
@@ -228,6 +231,35 @@ func (s *Service) subscribeToRedisUpdates() {
 			if err := json.Unmarshal(update.Data, &entry); err == nil {
 				s.BroadcastEntry(update.FestivalID, &entry)
 			}
+		case "activity":
+			if err := s.hub.BroadcastActivity(update.FestivalID, update.Data); err != nil {
+				log.Error().Err(err).
+					Str("festival_id", update.FestivalID).
+					Msg("Failed to broadcast activity")
+			}
+		case "menu_update":
+			if err := s.hub.BroadcastMenuUpdate(update.FestivalID, update.Data); err != nil {
+				log.Error().Err(err).
+					Str("festival_id", update.FestivalID).
+					Msg("Failed to broadcast menu update")
+			}
+		case "job":
+			if err := s.hub.BroadcastJob(update.FestivalID, update.Data); err != nil {
+				log.Error().Err(err).
+					Str("festival_id", update.FestivalID).
+					Msg("Failed to broadcast job")
+			}
+		case "credential_revoked":
+			if err := s.hub.BroadcastCredentialRevoked(update.FestivalID, update.Data); err != nil {
+				log.Error().Err(err).
+					Str("festival_id", update.FestivalID).
+					Msg("Failed to broadcast credential revocation")
+			}
+		case "display_revoked":
+			var revoked displayRevocation
+			if err := json.Unmarshal(update.Data, &revoked); err == nil {
+				s.disconnectDisplay(update.FestivalID, revoked.TokenID, revoked.Revocation)
+			}
 		}
 	}
 }
@@ -328,12 +360,143 @@ func (s *Service) BroadcastRevenueUpdate(festivalID string, point *RevenuePoint)
 	}
 }
 
+// BroadcastActivity broadcasts an activity feed entry to the dashboards of a festival,
+// through Redis when available so that the clients of every instance receive it
+func (s *Service) BroadcastActivity(ctx context.Context, festivalID string, activity interface{}) {
+	if s.redis != nil {
+		err := s.PublishToRedis(ctx, festivalID, "activity", activity)
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to publish activity, broadcasting locally")
+	}
+
+	if err := s.hub.BroadcastActivity(festivalID, activity); err != nil {
+		log.Error().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to broadcast activity")
+	}
+}
+
+// BroadcastMenuUpdate tells the apps and stand terminals of a festival to reload the
+// menus, e.g. when products are recalled, through Redis when available
+func (s *Service) BroadcastMenuUpdate(ctx context.Context, festivalID string, update interface{}) {
+	if s.redis != nil {
+		err := s.PublishToRedis(ctx, festivalID, "menu_update", update)
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to publish menu update, broadcasting locally")
+	}
+
+	if err := s.hub.BroadcastMenuUpdate(festivalID, update); err != nil {
+		log.Error().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to broadcast menu update")
+	}
+}
+
+// BroadcastJob pushes a background job event to the dashboards of a festival, through
+// Redis when available
+func (s *Service) BroadcastJob(ctx context.Context, festivalID string, job *Job) {
+	if s.redis != nil {
+		err := s.PublishToRedis(ctx, festivalID, "job", job)
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to publish job, broadcasting locally")
+	}
+
+	if err := s.hub.BroadcastJob(festivalID, job); err != nil {
+		log.Error().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to broadcast job")
+	}
+}
+
+// BroadcastCredentialRevocation tells the POS devices of a festival to refuse a revoked
+// wristband or QR generation, e.g. after a lost wristband was replaced, through Redis
+// when available so that the devices connected to every instance stop accepting it
+func (s *Service) BroadcastCredentialRevocation(ctx context.Context, festivalID string, revocation interface{}) {
+	if s.redis != nil {
+		err := s.PublishToRedis(ctx, festivalID, "credential_revoked", revocation)
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to publish credential revocation, broadcasting locally")
+	}
+
+	if err := s.hub.BroadcastCredentialRevoked(festivalID, revocation); err != nil {
+		log.Error().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to broadcast credential revocation")
+	}
+}
+
+// displayRevocation relays the revocation of a display token through Redis
+type displayRevocation struct {
+	TokenID    string          `json:"token_id"`
+	Revocation json.RawMessage `json:"revocation"`
+}
+
+// DisconnectDisplay closes the menu board connections of a revoked display token, through
+// Redis when available so that the boards connected to every instance are cut off
+func (s *Service) DisconnectDisplay(ctx context.Context, festivalID, tokenID string, revocation interface{}) {
+	data, err := json.Marshal(revocation)
+	if err != nil {
+		log.Error().Err(err).Str("display_token_id", tokenID).Msg("Failed to encode display revocation")
+		return
+	}
+
+	if s.redis != nil {
+		err := s.PublishToRedis(ctx, festivalID, "display_revoked", displayRevocation{TokenID: tokenID, Revocation: data})
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).
+			Str("festival_id", festivalID).
+			Msg("Failed to publish display revocation, disconnecting locally")
+	}
+
+	s.disconnectDisplay(festivalID, tokenID, data)
+}
+
+func (s *Service) disconnectDisplay(festivalID, tokenID string, revocation json.RawMessage) {
+	count, err := s.hub.DisconnectClients(festivalID, websocket.DisplayTokenKey, tokenID, websocket.MessageTypeDisplayRevoked, revocation)
+	if err != nil {
+		log.Error().Err(err).
+			Str("festival_id", festivalID).
+			Str("display_token_id", tokenID).
+			Msg("Failed to disconnect revoked display")
+		return
+	}
+	if count > 0 {
+		log.Info().
+			Str("festival_id", festivalID).
+			Str("display_token_id", tokenID).
+			Int("connections", count).
+			Msg("Disconnected revoked display")
+	}
+}
+
 // PublishToRedis publishes an update to Redis for distributed systems
 func (s *Service) PublishToRedis(ctx context.Context, festivalID string, msgType string, data interface{}) error {
 	if s.redis == nil {
 		return nil
 	}
+	return publish(ctx, s.redis, festivalID, msgType, data)
+}
 
+// publish sends an update to the instances subscribed to the festival updates channel
+func publish(ctx context.Context, client *redis.Client, festivalID string, msgType string, data interface{}) error {
 	payload := map[string]interface{}{
 		"festival_id": festivalID,
 		"type":        msgType,
@@ -345,7 +508,7 @@ func (s *Service) PublishToRedis(ctx context.Context, festivalID string, msgType
 		return err
 	}
 
-	return s.redis.Publish(ctx, "festival:updates", jsonData).Err()
+	return client.Publish(ctx, updatesChannel, jsonData).Err()
 }
 
 // SimulateStatsUpdate simulates stats updates for demo/testing
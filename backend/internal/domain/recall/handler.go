@@ -0,0 +1,221 @@
+package recall
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped product recalls, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	recalls := r.Group("/recalls")
+	{
+		recalls.GET("", h.List)
+		recalls.POST("", h.Create)
+		recalls.GET("/:recallId", h.Get)
+		recalls.POST("/:recallId/lift", h.Lift)
+	}
+}
+
+// List lists the product recalls of the festival
+// @Summary List product recalls
+// @Description List the product recalls of the festival, latest first
+// @Tags recalls
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(ACTIVE, LIFTED)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Recall,meta=response.Meta} "Product recalls"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/recalls [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	status := Status(c.Query("status"))
+	switch status {
+	case "", StatusActive, StatusLifted:
+	default:
+		response.BadRequest(c, "INVALID_STATUS", "Status must be ACTIVE or LIFTED", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	recalls, total, err := h.service.List(c.Request.Context(), festivalID, RecallFilter{
+		Status: status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, recalls, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Create recalls a product at every stand of the festival
+// @Summary Recall a product
+// @Description Disable products at every stand of the festival, by ID or SKU, cancel the unpaid orders containing them, reload the menus and queue the notification of the purchasers, with a refund of their wallet orders when refundPurchases is set
+// @Tags recalls
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateRecallRequest true "Recalled products"
+// @Success 201 {object} response.Response{data=Recall} "Product recall"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "No matching product"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/recalls [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateRecallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	recall, err := h.service.Create(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoProductTarget):
+			response.BadRequest(c, "NO_PRODUCT_TARGET", err.Error(), nil)
+		case errors.Is(err, ErrNoProducts):
+			response.NotFound(c, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.Created(c, recall)
+}
+
+// Get returns a product recall
+// @Summary Get a product recall
+// @Description Get a product recall with the outcome of the purchaser notification
+// @Tags recalls
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param recallId path string true "Recall ID" format(uuid)
+// @Success 200 {object} response.Response{data=Recall} "Product recall"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Recall not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/recalls/{recallId} [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, recallID, ok := recallParams(c)
+	if !ok {
+		return
+	}
+
+	recall, err := h.service.Get(c.Request.Context(), festivalID, recallID)
+	if err != nil {
+		if errors.Is(err, ErrRecallNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, recall)
+}
+
+// Lift ends a product recall
+// @Summary Lift a product recall
+// @Description End a product recall. Its products are set inactive, for each stand to put them back on sale, unless another active recall covers them.
+// @Tags recalls
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param recallId path string true "Recall ID" format(uuid)
+// @Success 200 {object} response.Response{data=Recall} "Lifted recall"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Recall not found"
+// @Failure 409 {object} response.ErrorResponse "Recall already lifted"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/recalls/{recallId}/lift [post]
+func (h *Handler) Lift(c *gin.Context) {
+	festivalID, recallID, ok := recallParams(c)
+	if !ok {
+		return
+	}
+
+	recall, err := h.service.Lift(c.Request.Context(), festivalID, recallID, userID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRecallNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, ErrAlreadyLifted):
+			response.Conflict(c, "ALREADY_LIFTED", err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, recall)
+}
+
+func recallParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	recallID, err := uuid.Parse(c.Param("recallId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid recall ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, recallID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package recall
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recall errors
+var (
+	ErrRecallNotFound  = errors.New("recall not found")
+	ErrNoProductTarget = errors.New("a recall needs productIds or a sku")
+	ErrNoProducts      = errors.New("no product of the festival matches the recall")
+	ErrAlreadyLifted   = errors.New("recall was already lifted")
+)
+
+// Status is the state of a recall
+type Status string
+
+const (
+	StatusActive Status = "ACTIVE" // Products disabled at every stand
+	StatusLifted Status = "LIFTED" // Products left inactive for the stands to review
+)
+
+// Recall disables a product across every stand of a festival, e.g. when a food batch
+// must be withdrawn, and tells the attendees who bought it
+type Recall struct {
+	ID               uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID       uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
+	SKU              string      `json:"sku,omitempty"`
+	ProductName      string      `json:"productName" gorm:"not null"`
+	ProductIDs       []uuid.UUID `json:"productIds" gorm:"type:jsonb;serializer:json"` // Disabled products, at any stand
+	StandIDs         []uuid.UUID `json:"standIds" gorm:"type:jsonb;serializer:json"`   // Stands selling them
+	Reason           string      `json:"reason" gorm:"not null"`
+	SoldSince        *time.Time  `json:"soldSince,omitempty"` // Purchases before are not affected; nil for all
+	RefundPurchases  bool        `json:"refundPurchases"`
+	Status           Status      `json:"status" gorm:"default:'ACTIVE'"`
+	PendingCancelled int         `json:"pendingCancelled"` // Unpaid orders cancelled by the recall
+	// Set by the purchaser notification job
+	OrdersAffected     int        `json:"ordersAffected"`
+	OrdersRefunded     int        `json:"ordersRefunded"`
+	RefundsFailed      int        `json:"refundsFailed"` // To settle at the info desk
+	PurchasersNotified int        `json:"purchasersNotified"`
+	NotifiedAt         *time.Time `json:"notifiedAt,omitempty"`
+	CreatedBy          *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	LiftedBy           *uuid.UUID `json:"liftedBy,omitempty" gorm:"type:uuid"`
+	LiftedAt           *time.Time `json:"liftedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+}
+
+func (Recall) TableName() string {
+	return "product_recalls"
+}
+
+// CreateRecallRequest selects the recalled products by ID, by SKU across every stand,
+// or both
+type CreateRecallRequest struct {
+	ProductIDs      []uuid.UUID `json:"productIds"`
+	SKU             string      `json:"sku" binding:"max=100"`
+	Reason          string      `json:"reason" binding:"required,max=500"`
+	SoldSince       *time.Time  `json:"soldSince"`
+	RefundPurchases bool        `json:"refundPurchases"` // Refund the wallet orders automatically
+}
+
+// RecalledProduct is a product matched by a recall
+type RecalledProduct struct {
+	ID      uuid.UUID
+	StandID uuid.UUID
+	Name    string
+}
+
+// Purchase is a paid order containing a recalled product
+type Purchase struct {
+	OrderID       uuid.UUID
+	UserID        *uuid.UUID // Nil for anonymous wallets
+	Email         string
+	Locale        string
+	StandName     string
+	PaymentMethod string
+	TotalAmount   int64
+	PurchasedAt   time.Time
+}
+
+// FestivalInfo is what the notices need of the festival
+type FestivalInfo struct {
+	Name         string
+	CurrencyName string
+}
+
+// NoticeStatus selects the wording of a recall notice
+type NoticeStatus string
+
+const (
+	NoticeRefunded NoticeStatus = "refunded" // Every purchase was refunded to the wallet
+	NoticeClaim    NoticeStatus = "claim"    // Refund to claim at the info desk
+	NoticeWarning  NoticeStatus = "warning"  // No refund offered
+)
+
+// Notice tells an attendee that a product they bought was recalled
+type Notice struct {
+	To             string
+	Locale         string
+	FestivalID     uuid.UUID
+	FestivalName   string
+	ProductName    string
+	Reason         string
+	StandName      string
+	PurchasedAt    time.Time // Latest purchase
+	Status         NoticeStatus
+	RefundedAmount int64
+	CurrencyName   string
+}
+
+// MenuUpdate is broadcast to the festival clients when products are recalled or a
+// recall is lifted
+type MenuUpdate struct {
+	Reason     string      `json:"reason"` // recall or recall_lifted
+	RecallID   uuid.UUID   `json:"recallId"`
+	ProductIDs []uuid.UUID `json:"productIds"`
+	StandIDs   []uuid.UUID `json:"standIds"`
+}
+
+// RecallFilter filters the listed recalls
+type RecallFilter struct {
+	Status Status
+	Offset int
+	Limit  int
+}
@@ -0,0 +1,229 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// pendingCancelNote is recorded on the unpaid orders cancelled by a recall
+const pendingCancelNote = "Cancelled by product recall"
+
+type Repository interface {
+	ResolveProducts(ctx context.Context, festivalID uuid.UUID, productIDs []uuid.UUID, sku string) ([]RecalledProduct, error)
+	// Create records a recall, disables its products and cancels the unpaid orders
+	// containing them, in one transaction
+	Create(ctx context.Context, recall *Recall) error
+	// Lift records a lifted recall and sets its products inactive, unless another
+	// active recall covers them
+	Lift(ctx context.Context, recall *Recall) error
+	Get(ctx context.Context, festivalID, id uuid.UUID) (*Recall, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Recall, error)
+	List(ctx context.Context, festivalID uuid.UUID, filter RecallFilter) ([]Recall, int64, error)
+	Update(ctx context.Context, recall *Recall) error
+	GetFestivalInfo(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error)
+	// ListPurchases lists the paid orders containing a recalled product, by purchaser
+	ListPurchases(ctx context.Context, recall *Recall) ([]Purchase, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ResolveProducts returns the festival products with one of productIDs or the sku,
+// at any stand
+func (r *repository) ResolveProducts(ctx context.Context, festivalID uuid.UUID, productIDs []uuid.UUID, sku string) ([]RecalledProduct, error) {
+	query := r.db.WithContext(ctx).
+		Table("public.products p").
+		Select("p.id, p.stand_id, p.name").
+		Joins("INNER JOIN public.stands s ON s.id = p.stand_id").
+		Where("s.festival_id = ?", festivalID)
+
+	switch {
+	case len(productIDs) > 0 && sku != "":
+		query = query.Where("p.id IN ? OR p.sku = ?", productIDs, sku)
+	case len(productIDs) > 0:
+		query = query.Where("p.id IN ?", productIDs)
+	default:
+		query = query.Where("p.sku = ?", sku)
+	}
+
+	var products []RecalledProduct
+	if err := query.Order("p.name, p.id").Scan(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve recalled products: %w", err)
+	}
+	return products, nil
+}
+
+func (r *repository) Create(ctx context.Context, recall *Recall) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(recall).Error; err != nil {
+			return fmt.Errorf("failed to create recall: %w", err)
+		}
+
+		if err := tx.Exec(`
+			UPDATE public.products SET status = 'RECALLED', updated_at = ?
+			WHERE id IN ?`,
+			recall.CreatedAt, recall.ProductIDs,
+		).Error; err != nil {
+			return fmt.Errorf("failed to disable recalled products: %w", err)
+		}
+
+		// Orders rung up but not paid yet would otherwise still be sold
+		var cancelled int
+		if err := tx.Raw(`
+			WITH cancelled AS (
+				UPDATE public.orders o
+				SET status = 'CANCELLED', notes = ?, updated_at = ?
+				WHERE o.festival_id = ? AND o.status = 'PENDING'
+					AND EXISTS (SELECT 1 FROM jsonb_array_elements(o.items) i WHERE i->>'productId' IN ?)
+				RETURNING o.id
+			), released AS (
+				UPDATE public.stock_reservations
+				SET status = 'RELEASED', updated_at = ?
+				WHERE order_id IN (SELECT id FROM cancelled) AND status = 'HELD'
+			)
+			SELECT COUNT(*) FROM cancelled`,
+			pendingCancelNote, recall.CreatedAt, recall.FestivalID, productIDStrings(recall.ProductIDs), recall.CreatedAt,
+		).Scan(&cancelled).Error; err != nil {
+			return fmt.Errorf("failed to cancel pending orders: %w", err)
+		}
+
+		if cancelled > 0 {
+			recall.PendingCancelled = cancelled
+			if err := tx.Model(recall).Update("pending_cancelled", cancelled).Error; err != nil {
+				return fmt.Errorf("failed to update recall: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *repository) Lift(ctx context.Context, recall *Recall) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(recall).Error; err != nil {
+			return fmt.Errorf("failed to lift recall: %w", err)
+		}
+
+		if err := tx.Exec(`
+			UPDATE public.products p SET status = 'INACTIVE', updated_at = ?
+			WHERE p.id IN ? AND p.status = 'RECALLED'
+				AND NOT EXISTS (
+					SELECT 1 FROM public.product_recalls r
+					WHERE r.status = 'ACTIVE' AND r.id <> ? AND jsonb_exists(r.product_ids, p.id::text)
+				)`,
+			recall.UpdatedAt, recall.ProductIDs, recall.ID,
+		).Error; err != nil {
+			return fmt.Errorf("failed to restore recalled products: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Recall, error) {
+	var recall Recall
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&recall).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get recall: %w", err)
+	}
+	return &recall, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Recall, error) {
+	var recall Recall
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&recall).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get recall: %w", err)
+	}
+	return &recall, nil
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, filter RecallFilter) ([]Recall, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Recall{}).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count recalls: %w", err)
+	}
+
+	var recalls []Recall
+	if err := query.Order("created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&recalls).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list recalls: %w", err)
+	}
+	return recalls, total, nil
+}
+
+func (r *repository) Update(ctx context.Context, recall *Recall) error {
+	if err := r.db.WithContext(ctx).Save(recall).Error; err != nil {
+		return fmt.Errorf("failed to update recall: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetFestivalInfo(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	var info FestivalInfo
+	result := r.db.WithContext(ctx).Raw(`
+		SELECT name, currency_name FROM public.festivals WHERE id = ?`,
+		festivalID,
+	).Scan(&info)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func (r *repository) ListPurchases(ctx context.Context, recall *Recall) ([]Purchase, error) {
+	soldSince := time.Time{}
+	if recall.SoldSince != nil {
+		soldSince = *recall.SoldSince
+	}
+
+	var purchases []Purchase
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT o.id AS order_id, o.user_id, COALESCE(u.email, '') AS email,
+			COALESCE(p.preferred_language, '') AS locale,
+			COALESCE(s.name, '') AS stand_name, o.payment_method, o.total_amount,
+			o.created_at AS purchased_at
+		FROM public.orders o
+		LEFT JOIN public.stands s ON s.id = o.stand_id
+		LEFT JOIN public.users u ON u.id = o.user_id
+		LEFT JOIN public.user_notification_preferences p ON p.user_id = o.user_id
+		WHERE o.festival_id = ? AND o.status = 'PAID' AND o.created_at >= ?
+			AND EXISTS (SELECT 1 FROM jsonb_array_elements(o.items) i WHERE i->>'productId' IN ?)
+		ORDER BY o.user_id, o.created_at`,
+		recall.FestivalID, soldSince, productIDStrings(recall.ProductIDs),
+	).Scan(&purchases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recalled purchases: %w", err)
+	}
+	return purchases, nil
+}
+
+// productIDStrings formats product IDs as stored in the order items
+func productIDStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
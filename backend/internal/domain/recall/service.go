@@ -0,0 +1,365 @@
+package recall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeNotifyPurchasers is the worker task refunding and notifying the purchasers of a
+// recalled product
+const TypeNotifyPurchasers = "product:notify_recall"
+
+// refundReason is the description of the refunds of recalled purchases
+const refundReason = "Product recall"
+
+// NotifyTaskPayload identifies the recall processed by a task
+type NotifyTaskPayload struct {
+	RecallID uuid.UUID `json:"recallId"`
+}
+
+// NewNotifyPurchasersTask creates a task that refunds and notifies the purchasers of a
+// recalled product
+func NewNotifyPurchasersTask(recallID uuid.UUID) (*asynq.Task, error) {
+	data, err := json.Marshal(NotifyTaskPayload{RecallID: recallID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeNotifyPurchasers, data), nil
+}
+
+// OrderRefunder refunds paid orders; satisfied by order.Service
+type OrderRefunder interface {
+	RefundOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*order.Order, error)
+}
+
+// Notifier tells attendees about recalled purchases; satisfied by jobs.EmailQueue
+type Notifier interface {
+	NotifyRecall(ctx context.Context, notice Notice) error
+}
+
+// MenuRefresher recomputes the menu recommendations of a festival, which only suggest
+// active products; satisfied by recommendation.Service
+type MenuRefresher interface {
+	Refresh(ctx context.Context, festivalID uuid.UUID) error
+}
+
+// Broadcaster tells the apps and stand terminals to reload the menus; satisfied by
+// realtime.Service
+type Broadcaster interface {
+	BroadcastMenuUpdate(ctx context.Context, festivalID string, update interface{})
+}
+
+// Service recalls products across the stands of a festival. The API disables the
+// products and invalidates the menus at once; the worker then refunds and notifies the
+// purchasers.
+type Service struct {
+	repo        Repository
+	queueClient *queue.Client
+	refunder    OrderRefunder
+	notifier    Notifier
+	menus       MenuRefresher
+	broadcaster Broadcaster
+	jobs        realtime.JobBroadcaster
+	now         func() time.Time
+}
+
+// NewService creates a new recall service. queueClient may be nil, in which case the
+// purchasers are not notified.
+func NewService(repo Repository, queueClient *queue.Client) *Service {
+	return &Service{
+		repo:        repo,
+		queueClient: queueClient,
+		now:         time.Now,
+	}
+}
+
+// SetOrderRefunder enables the refund of recalled purchases
+func (s *Service) SetOrderRefunder(refunder OrderRefunder) {
+	s.refunder = refunder
+}
+
+// SetNotifier notifies the purchasers of recalled products
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// SetMenuRefresher drops recalled products from the menu recommendations
+func (s *Service) SetMenuRefresher(menus MenuRefresher) {
+	s.menus = menus
+}
+
+// SetBroadcaster pushes menu updates to the festival clients
+func (s *Service) SetBroadcaster(broadcaster Broadcaster) {
+	s.broadcaster = broadcaster
+}
+
+// SetJobBroadcaster reports the progress of the refunds and notices to the dashboards
+func (s *Service) SetJobBroadcaster(jobs realtime.JobBroadcaster) {
+	s.jobs = jobs
+}
+
+// trackJob tracks the refunds and notices of a recall for the jobs panel of the dashboard
+func (s *Service) trackJob(recall *Recall) *realtime.JobTracker {
+	title := fmt.Sprintf("Recall of %s: refunds and notices", recall.ProductName)
+	return realtime.TrackJob(s.jobs, recall.FestivalID.String(), recall.ID.String(), realtime.JobKindRecallRefunds, title)
+}
+
+// Create recalls the products of a festival matching the request at every stand, and
+// queues the notification of their purchasers
+func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, req CreateRecallRequest, createdBy *uuid.UUID) (*Recall, error) {
+	if len(req.ProductIDs) == 0 && req.SKU == "" {
+		return nil, ErrNoProductTarget
+	}
+
+	products, err := s.repo.ResolveProducts(ctx, festivalID, req.ProductIDs, req.SKU)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrNoProducts
+	}
+
+	now := s.now()
+	recall := &Recall{
+		ID:              uuid.New(),
+		FestivalID:      festivalID,
+		SKU:             req.SKU,
+		ProductName:     products[0].Name,
+		Reason:          req.Reason,
+		SoldSince:       req.SoldSince,
+		RefundPurchases: req.RefundPurchases,
+		Status:          StatusActive,
+		CreatedBy:       createdBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	recall.ProductIDs, recall.StandIDs = productScope(products)
+
+	if err := s.repo.Create(ctx, recall); err != nil {
+		return nil, err
+	}
+
+	s.invalidateMenus(ctx, recall, "recall")
+
+	if s.queueClient != nil {
+		task, err := NewNotifyPurchasersTask(recall.ID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.queueClient.EnqueueCritical(ctx, task,
+			asynq.TaskID(fmt.Sprintf("recall_notify_%s", recall.ID)),
+			asynq.MaxRetry(3),
+		); err != nil {
+			// The products stay recalled; notifiedAt is left empty
+			log.Error().Err(err).Str("recall_id", recall.ID.String()).Msg("Failed to queue recall notification")
+		} else {
+			s.trackJob(recall).Queued(ctx)
+		}
+	}
+
+	return recall, nil
+}
+
+// Lift ends a recall. Its products are left inactive, for each stand to put them back
+// on sale once the stock is safe.
+func (s *Service) Lift(ctx context.Context, festivalID, id uuid.UUID, liftedBy *uuid.UUID) (*Recall, error) {
+	recall, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if recall.Status == StatusLifted {
+		return nil, ErrAlreadyLifted
+	}
+
+	now := s.now()
+	recall.Status = StatusLifted
+	recall.LiftedBy = liftedBy
+	recall.LiftedAt = &now
+	recall.UpdatedAt = now
+	if err := s.repo.Lift(ctx, recall); err != nil {
+		return nil, err
+	}
+
+	s.invalidateMenus(ctx, recall, "recall_lifted")
+	return recall, nil
+}
+
+// HandleNotifyPurchasers handles the notification task queued by a recall
+func (s *Service) HandleNotifyPurchasers(ctx context.Context, t *asynq.Task) error {
+	var payload NotifyTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return s.NotifyPurchasers(ctx, payload.RecallID)
+}
+
+// NotifyPurchasers refunds the wallet orders containing the recalled products when the
+// recall offers refunds, and sends each purchaser a single notice. Refunds that fail,
+// e.g. of closed business days, and orders paid in cash or by card are left to claim
+// at the info desk. A recall is only processed once.
+func (s *Service) NotifyPurchasers(ctx context.Context, recallID uuid.UUID) error {
+	recall, err := s.repo.GetByID(ctx, recallID)
+	if err != nil {
+		return err
+	}
+	if recall == nil || recall.NotifiedAt != nil {
+		return nil
+	}
+
+	festival, err := s.repo.GetFestivalInfo(ctx, recall.FestivalID)
+	if err != nil {
+		return err
+	}
+	if festival == nil {
+		return nil
+	}
+
+	job := s.trackJob(recall)
+	job.Started(ctx)
+
+	purchases, err := s.repo.ListPurchases(ctx, recall)
+	if err != nil {
+		job.Failed(ctx, err)
+		return err
+	}
+
+	var notices []*Notice
+	byUser := make(map[uuid.UUID]*Notice)
+	for i, purchase := range purchases {
+		refunded := s.refund(ctx, recall, purchase)
+		job.Progress(ctx, i+1, len(purchases))
+
+		if purchase.UserID == nil || purchase.Email == "" {
+			continue
+		}
+		notice, exists := byUser[*purchase.UserID]
+		if !exists {
+			notice = &Notice{
+				To:           purchase.Email,
+				Locale:       purchase.Locale,
+				FestivalID:   recall.FestivalID,
+				FestivalName: festival.Name,
+				ProductName:  recall.ProductName,
+				Reason:       recall.Reason,
+				Status:       NoticeRefunded,
+				CurrencyName: festival.CurrencyName,
+			}
+			byUser[*purchase.UserID] = notice
+			notices = append(notices, notice)
+		}
+		// Purchases are listed oldest first
+		notice.StandName = purchase.StandName
+		notice.PurchasedAt = purchase.PurchasedAt
+		switch {
+		case refunded:
+			notice.RefundedAmount += purchase.TotalAmount
+		case recall.RefundPurchases:
+			notice.Status = NoticeClaim
+		default:
+			notice.Status = NoticeWarning
+		}
+	}
+	recall.OrdersAffected = len(purchases)
+
+	if s.notifier != nil {
+		for _, notice := range notices {
+			if err := s.notifier.NotifyRecall(ctx, *notice); err != nil {
+				log.Error().Err(err).Str("recall_id", recall.ID.String()).Msg("Failed to notify recall purchaser")
+				continue
+			}
+			recall.PurchasersNotified++
+		}
+	}
+
+	now := s.now()
+	recall.NotifiedAt = &now
+	recall.UpdatedAt = now
+	if err := s.repo.Update(ctx, recall); err != nil {
+		job.Failed(ctx, err)
+		return err
+	}
+	job.Completed(ctx, fmt.Sprintf("/api/v1/festivals/%s/recalls/%s", recall.FestivalID, recall.ID))
+	return nil
+}
+
+// refund refunds a recalled wallet purchase when the recall offers refunds, counting
+// the outcome on the recall
+func (s *Service) refund(ctx context.Context, recall *Recall, purchase Purchase) bool {
+	if !recall.RefundPurchases {
+		return false
+	}
+	if s.refunder == nil || purchase.PaymentMethod != order.PaymentMethodWallet {
+		recall.RefundsFailed++
+		return false
+	}
+
+	if _, err := s.refunder.RefundOrder(ctx, purchase.OrderID, refundReason, nil); err != nil {
+		log.Error().Err(err).
+			Str("recall_id", recall.ID.String()).
+			Str("order_id", purchase.OrderID.String()).
+			Msg("Failed to refund recalled purchase")
+		recall.RefundsFailed++
+		return false
+	}
+	recall.OrdersRefunded++
+	return true
+}
+
+// invalidateMenus drops the recalled products from the menu recommendations and tells
+// the festival clients to reload the menus of the affected stands
+func (s *Service) invalidateMenus(ctx context.Context, recall *Recall, reason string) {
+	if s.menus != nil {
+		if err := s.menus.Refresh(ctx, recall.FestivalID); err != nil {
+			log.Error().Err(err).Str("recall_id", recall.ID.String()).Msg("Failed to refresh menu recommendations")
+		}
+	}
+	if s.broadcaster != nil {
+		s.broadcaster.BroadcastMenuUpdate(ctx, recall.FestivalID.String(), MenuUpdate{
+			Reason:     reason,
+			RecallID:   recall.ID,
+			ProductIDs: recall.ProductIDs,
+			StandIDs:   recall.StandIDs,
+		})
+	}
+}
+
+// Get returns a recall of a festival
+func (s *Service) Get(ctx context.Context, festivalID, id uuid.UUID) (*Recall, error) {
+	recall, err := s.repo.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if recall == nil {
+		return nil, ErrRecallNotFound
+	}
+	return recall, nil
+}
+
+// List lists the recalls of a festival, latest first
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, filter RecallFilter) ([]Recall, int64, error) {
+	return s.repo.List(ctx, festivalID, filter)
+}
+
+// productScope returns the IDs of the recalled products and of the stands selling them
+func productScope(products []RecalledProduct) ([]uuid.UUID, []uuid.UUID) {
+	productIDs := make([]uuid.UUID, 0, len(products))
+	standIDs := make([]uuid.UUID, 0, len(products))
+	seen := make(map[uuid.UUID]bool, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		if !seen[product.StandID] {
+			seen[product.StandID] = true
+			standIDs = append(standIDs, product.StandID)
+		}
+	}
+	return productIDs, standIDs
+}
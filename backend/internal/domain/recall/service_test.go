@@ -0,0 +1,193 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	products  []RecalledProduct
+	created   *Recall
+	recall    *Recall
+	purchases []Purchase
+	updated   *Recall
+}
+
+func (r *fakeRepository) ResolveProducts(ctx context.Context, festivalID uuid.UUID, productIDs []uuid.UUID, sku string) ([]RecalledProduct, error) {
+	return r.products, nil
+}
+
+func (r *fakeRepository) Create(ctx context.Context, recall *Recall) error {
+	r.created = recall
+	return nil
+}
+
+func (r *fakeRepository) Lift(ctx context.Context, recall *Recall) error {
+	return nil
+}
+
+func (r *fakeRepository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Recall, error) {
+	return r.recall, nil
+}
+
+func (r *fakeRepository) GetByID(ctx context.Context, id uuid.UUID) (*Recall, error) {
+	return r.recall, nil
+}
+
+func (r *fakeRepository) List(ctx context.Context, festivalID uuid.UUID, filter RecallFilter) ([]Recall, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRepository) Update(ctx context.Context, recall *Recall) error {
+	r.updated = recall
+	return nil
+}
+
+func (r *fakeRepository) GetFestivalInfo(ctx context.Context, festivalID uuid.UUID) (*FestivalInfo, error) {
+	return &FestivalInfo{Name: "Summer Fest", CurrencyName: "Tokens"}, nil
+}
+
+func (r *fakeRepository) ListPurchases(ctx context.Context, recall *Recall) ([]Purchase, error) {
+	return r.purchases, nil
+}
+
+type fakeRefunder struct {
+	failing  map[uuid.UUID]bool
+	refunded []uuid.UUID
+}
+
+func (f *fakeRefunder) RefundOrder(ctx context.Context, orderID uuid.UUID, reason string, staffID *uuid.UUID) (*order.Order, error) {
+	if f.failing[orderID] {
+		return nil, errors.New("the business day of this stand is closed")
+	}
+	f.refunded = append(f.refunded, orderID)
+	return &order.Order{ID: orderID}, nil
+}
+
+type fakeNotifier struct {
+	notices []Notice
+}
+
+func (n *fakeNotifier) NotifyRecall(ctx context.Context, notice Notice) error {
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+type fakeMenus struct {
+	refreshed []uuid.UUID
+	updates   []MenuUpdate
+}
+
+func (m *fakeMenus) Refresh(ctx context.Context, festivalID uuid.UUID) error {
+	m.refreshed = append(m.refreshed, festivalID)
+	return nil
+}
+
+func (m *fakeMenus) BroadcastMenuUpdate(ctx context.Context, festivalID string, update interface{}) {
+	m.updates = append(m.updates, update.(MenuUpdate))
+}
+
+func TestCreate_DisablesProductsAtEveryStand(t *testing.T) {
+	festivalID := uuid.New()
+	barA, barB := uuid.New(), uuid.New()
+	repo := &fakeRepository{products: []RecalledProduct{
+		{ID: uuid.New(), StandID: barA, Name: "Veggie Burger"},
+		{ID: uuid.New(), StandID: barB, Name: "Veggie Burger"},
+		{ID: uuid.New(), StandID: barA, Name: "Veggie Burger XL"},
+	}}
+	menus := &fakeMenus{}
+	service := NewService(repo, nil)
+	service.SetMenuRefresher(menus)
+	service.SetBroadcaster(menus)
+
+	_, err := service.Create(context.Background(), festivalID, CreateRecallRequest{Reason: "Listeria"}, nil)
+	assert.ErrorIs(t, err, ErrNoProductTarget)
+
+	recall, err := service.Create(context.Background(), festivalID, CreateRecallRequest{SKU: "VB-2607", Reason: "Listeria"}, nil)
+	require.NoError(t, err)
+	assert.Same(t, repo.created, recall)
+	assert.Equal(t, StatusActive, recall.Status)
+	assert.Equal(t, "Veggie Burger", recall.ProductName)
+	assert.Len(t, recall.ProductIDs, 3)
+	assert.Equal(t, []uuid.UUID{barA, barB}, recall.StandIDs)
+
+	assert.Equal(t, []uuid.UUID{festivalID}, menus.refreshed)
+	require.Len(t, menus.updates, 1)
+	assert.Equal(t, "recall", menus.updates[0].Reason)
+	assert.Equal(t, recall.ProductIDs, menus.updates[0].ProductIDs)
+
+	repo.products = nil
+	_, err = service.Create(context.Background(), festivalID, CreateRecallRequest{SKU: "UNKNOWN", Reason: "Listeria"}, nil)
+	assert.ErrorIs(t, err, ErrNoProducts)
+}
+
+func TestNotifyPurchasers_RefundsAndGroupsByPurchaser(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	closedDay := uuid.New()
+	at := time.Date(2026, 7, 18, 13, 0, 0, 0, time.UTC)
+	repo := &fakeRepository{
+		recall: &Recall{ID: uuid.New(), FestivalID: uuid.New(), ProductName: "Veggie Burger", Reason: "Listeria", RefundPurchases: true},
+		purchases: []Purchase{
+			{OrderID: uuid.New(), UserID: &alice, Email: "alice@example.com", StandName: "Grill", PaymentMethod: order.PaymentMethodWallet, TotalAmount: 1200, PurchasedAt: at},
+			{OrderID: uuid.New(), UserID: &alice, Email: "alice@example.com", StandName: "Food Truck", PaymentMethod: order.PaymentMethodWallet, TotalAmount: 800, PurchasedAt: at.Add(time.Hour)},
+			{OrderID: uuid.New(), UserID: &bob, Email: "bob@example.com", StandName: "Grill", PaymentMethod: order.PaymentMethodCash, TotalAmount: 1200, PurchasedAt: at},
+			{OrderID: closedDay, UserID: &bob, Email: "bob@example.com", StandName: "Grill", PaymentMethod: order.PaymentMethodWallet, TotalAmount: 600, PurchasedAt: at},
+			{OrderID: uuid.New(), PaymentMethod: order.PaymentMethodWallet, TotalAmount: 1200, PurchasedAt: at}, // Anonymous wallet
+		},
+	}
+	refunder := &fakeRefunder{failing: map[uuid.UUID]bool{closedDay: true}}
+	notifier := &fakeNotifier{}
+	service := NewService(repo, nil)
+	service.SetOrderRefunder(refunder)
+	service.SetNotifier(notifier)
+
+	require.NoError(t, service.NotifyPurchasers(context.Background(), repo.recall.ID))
+	assert.Len(t, refunder.refunded, 3)
+
+	require.Len(t, notifier.notices, 2)
+	assert.Equal(t, NoticeRefunded, notifier.notices[0].Status)
+	assert.Equal(t, int64(2000), notifier.notices[0].RefundedAmount)
+	assert.Equal(t, "Food Truck", notifier.notices[0].StandName)
+	assert.Equal(t, "Tokens", notifier.notices[0].CurrencyName)
+	assert.Equal(t, NoticeClaim, notifier.notices[1].Status)
+
+	require.NotNil(t, repo.updated)
+	assert.Equal(t, 5, repo.updated.OrdersAffected)
+	assert.Equal(t, 3, repo.updated.OrdersRefunded)
+	assert.Equal(t, 2, repo.updated.RefundsFailed)
+	assert.Equal(t, 2, repo.updated.PurchasersNotified)
+	require.NotNil(t, repo.updated.NotifiedAt)
+
+	// Processed once
+	notifier.notices = nil
+	require.NoError(t, service.NotifyPurchasers(context.Background(), repo.recall.ID))
+	assert.Empty(t, notifier.notices)
+}
+
+func TestNotifyPurchasers_WarnsWithoutRefund(t *testing.T) {
+	alice := uuid.New()
+	repo := &fakeRepository{
+		recall: &Recall{ID: uuid.New(), FestivalID: uuid.New(), ProductName: "Veggie Burger", Reason: "Listeria"},
+		purchases: []Purchase{
+			{OrderID: uuid.New(), UserID: &alice, Email: "alice@example.com", PaymentMethod: order.PaymentMethodWallet, TotalAmount: 1200},
+		},
+	}
+	refunder := &fakeRefunder{}
+	notifier := &fakeNotifier{}
+	service := NewService(repo, nil)
+	service.SetOrderRefunder(refunder)
+	service.SetNotifier(notifier)
+
+	require.NoError(t, service.NotifyPurchasers(context.Background(), repo.recall.ID))
+	assert.Empty(t, refunder.refunded)
+	require.Len(t, notifier.notices, 1)
+	assert.Equal(t, NoticeWarning, notifier.notices[0].Status)
+	assert.Equal(t, 0, repo.updated.RefundsFailed)
+}
@@ -0,0 +1,142 @@
+package recommendation
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ComputeStandRecommendations derives the recommendations of every stand from its paid
+// orders. A product Y is recommended with X when at least MinPairOrders orders contain
+// both and at least MinConfidence of the orders containing X also contain Y; the
+// confidence is the score. Top sellers are ranked by the quantity sold in the trending
+// window, scored by their share of it.
+func ComputeStandRecommendations(lines []BasketLine, sales []ProductSales, cfg Config, now time.Time) map[uuid.UUID]*StandRecommendations {
+	stands := make(map[uuid.UUID]*StandRecommendations)
+	standFor := func(standID uuid.UUID) *StandRecommendations {
+		s, ok := stands[standID]
+		if !ok {
+			s = &StandRecommendations{
+				StandID:    standID,
+				TopSellers: []Recommendation{},
+				AlsoBought: make(map[string][]Recommendation),
+				ComputedAt: now,
+			}
+			stands[standID] = s
+		}
+		return s
+	}
+
+	for standID, baskets := range groupBaskets(lines) {
+		standFor(standID).AlsoBought = alsoBought(baskets.orders, baskets.names, cfg)
+	}
+
+	totals := make(map[uuid.UUID]int64)
+	for _, s := range sales {
+		totals[s.StandID] += s.Quantity
+	}
+	for _, s := range sales {
+		stand := standFor(s.StandID)
+		stand.TopSellers = append(stand.TopSellers, Recommendation{
+			ProductID:   s.ProductID,
+			ProductName: s.ProductName,
+			Score:       roundTo(float64(s.Quantity)/float64(totals[s.StandID]), 3),
+			Orders:      s.Orders,
+		})
+	}
+	for _, stand := range stands {
+		sortRecommendations(stand.TopSellers)
+		if len(stand.TopSellers) > cfg.MaxTopSellers {
+			stand.TopSellers = stand.TopSellers[:cfg.MaxTopSellers]
+		}
+	}
+
+	return stands
+}
+
+// standBaskets is the distinct products of each order of a stand
+type standBaskets struct {
+	orders map[uuid.UUID][]uuid.UUID
+	names  map[uuid.UUID]string
+}
+
+func groupBaskets(lines []BasketLine) map[uuid.UUID]*standBaskets {
+	stands := make(map[uuid.UUID]*standBaskets)
+	for _, l := range lines {
+		b, ok := stands[l.StandID]
+		if !ok {
+			b = &standBaskets{orders: make(map[uuid.UUID][]uuid.UUID), names: make(map[uuid.UUID]string)}
+			stands[l.StandID] = b
+		}
+		b.orders[l.OrderID] = append(b.orders[l.OrderID], l.ProductID)
+		b.names[l.ProductID] = l.ProductName
+	}
+	return stands
+}
+
+// alsoBought counts how often each pair of products is bought together and keeps the
+// pairs confident enough
+func alsoBought(orders map[uuid.UUID][]uuid.UUID, names map[uuid.UUID]string, cfg Config) map[string][]Recommendation {
+	productOrders := make(map[uuid.UUID]int64)
+	pairOrders := make(map[[2]uuid.UUID]int64)
+	for _, products := range orders {
+		for i, x := range products {
+			productOrders[x]++
+			for _, y := range products[i+1:] {
+				if x == y {
+					continue
+				}
+				pairOrders[[2]uuid.UUID{x, y}]++
+				pairOrders[[2]uuid.UUID{y, x}]++
+			}
+		}
+	}
+
+	result := make(map[string][]Recommendation)
+	for pair, together := range pairOrders {
+		if together < int64(cfg.MinPairOrders) {
+			continue
+		}
+		confidence := float64(together) / float64(productOrders[pair[0]])
+		if confidence < cfg.MinConfidence {
+			continue
+		}
+		key := pair[0].String()
+		result[key] = append(result[key], Recommendation{
+			ProductID:   pair[1],
+			ProductName: names[pair[1]],
+			Score:       roundTo(confidence, 3),
+			Orders:      together,
+		})
+	}
+
+	for key, recommendations := range result {
+		sortRecommendations(recommendations)
+		if len(recommendations) > cfg.MaxAlsoBought {
+			result[key] = recommendations[:cfg.MaxAlsoBought]
+		}
+	}
+	return result
+}
+
+// sortRecommendations sorts by score, then by supporting orders, then by name so that
+// the order is stable between refreshes
+func sortRecommendations(recommendations []Recommendation) {
+	sort.Slice(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Orders != b.Orders {
+			return a.Orders > b.Orders
+		}
+		return a.ProductName < b.ProductName
+	})
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,145 @@
+package recommendation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func basket(standID uuid.UUID, products ...uuid.UUID) []BasketLine {
+	orderID := uuid.New()
+	lines := make([]BasketLine, len(products))
+	for i, p := range products {
+		lines[i] = BasketLine{OrderID: orderID, StandID: standID, ProductID: p, ProductName: p.String()[:8]}
+	}
+	return lines
+}
+
+func TestComputeStandRecommendations(t *testing.T) {
+	now := time.Date(2026, 7, 18, 20, 0, 0, 0, time.UTC)
+	standID := uuid.New()
+	burger, fries, beer, water := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	var lines []BasketLine
+	// 4 orders of burger: 3 with fries, 1 with beer
+	for i := 0; i < 3; i++ {
+		lines = append(lines, basket(standID, burger, fries)...)
+	}
+	lines = append(lines, basket(standID, burger, beer)...)
+	// 2 orders of fries alone
+	lines = append(lines, basket(standID, fries)...)
+	lines = append(lines, basket(standID, fries)...)
+	lines = append(lines, basket(standID, water)...)
+
+	sales := []ProductSales{
+		{StandID: standID, ProductID: burger, ProductName: "Burger", Quantity: 6, Orders: 4},
+		{StandID: standID, ProductID: beer, ProductName: "Beer", Quantity: 10, Orders: 5},
+		{StandID: standID, ProductID: water, ProductName: "Water", Quantity: 4, Orders: 4},
+	}
+
+	cfg := DefaultConfig()
+	stands := ComputeStandRecommendations(lines, sales, cfg, now)
+	require.Contains(t, stands, standID)
+	stand := stands[standID]
+	assert.Equal(t, now, stand.ComputedAt)
+
+	// Burger and fries were bought together 3 times: 3/4 of burger orders, 3/5 of fries orders
+	require.Len(t, stand.AlsoBought[burger.String()], 1)
+	assert.Equal(t, fries, stand.AlsoBought[burger.String()][0].ProductID)
+	assert.Equal(t, 0.75, stand.AlsoBought[burger.String()][0].Score)
+	assert.Equal(t, int64(3), stand.AlsoBought[burger.String()][0].Orders)
+	require.Len(t, stand.AlsoBought[fries.String()], 1)
+	assert.Equal(t, 0.6, stand.AlsoBought[fries.String()][0].Score)
+
+	// Burger and beer were bought together once only, below MinPairOrders
+	assert.Empty(t, stand.AlsoBought[beer.String()])
+	assert.Empty(t, stand.AlsoBought[water.String()])
+
+	require.Len(t, stand.TopSellers, 3)
+	assert.Equal(t, beer, stand.TopSellers[0].ProductID)
+	assert.Equal(t, 0.5, stand.TopSellers[0].Score)
+	assert.Equal(t, burger, stand.TopSellers[1].ProductID)
+	assert.Equal(t, water, stand.TopSellers[2].ProductID)
+}
+
+func TestComputeStandRecommendations_Limits(t *testing.T) {
+	now := time.Now()
+	standID, otherStandID := uuid.New(), uuid.New()
+	products := make([]uuid.UUID, 4)
+	for i := range products {
+		products[i] = uuid.New()
+	}
+
+	var lines []BasketLine
+	for i := 0; i < 3; i++ {
+		lines = append(lines, basket(standID, products...)...)
+	}
+	var sales []ProductSales
+	for i, p := range products {
+		sales = append(sales, ProductSales{StandID: standID, ProductID: p, Quantity: int64(i + 1), Orders: 1})
+	}
+	sales = append(sales, ProductSales{StandID: otherStandID, ProductID: uuid.New(), Quantity: 1, Orders: 1})
+
+	cfg := DefaultConfig()
+	cfg.MaxAlsoBought = 2
+	cfg.MaxTopSellers = 3
+	stands := ComputeStandRecommendations(lines, sales, cfg, now)
+
+	require.Len(t, stands, 2)
+	assert.Len(t, stands[standID].AlsoBought[products[0].String()], 2)
+	require.Len(t, stands[standID].TopSellers, 3)
+	assert.Equal(t, products[3], stands[standID].TopSellers[0].ProductID)
+
+	// Stands with recent sales but no baskets in the window only have top sellers
+	assert.Empty(t, stands[otherStandID].AlsoBought)
+	assert.Len(t, stands[otherStandID].TopSellers, 1)
+	assert.Equal(t, 1.0, stands[otherStandID].TopSellers[0].Score)
+}
+
+func TestBuildMenuRecommendations(t *testing.T) {
+	now := time.Now()
+	standID := uuid.New()
+	burger, fries, beer, soda, cake := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	stand := &StandRecommendations{
+		StandID: standID,
+		TopSellers: []Recommendation{
+			{ProductID: beer, ProductName: "Beer", Score: 0.4},
+			{ProductID: burger, ProductName: "Burger", Score: 0.3},
+			{ProductID: cake, ProductName: "Cake", Score: 0.1},
+		},
+		AlsoBought: map[string][]Recommendation{
+			burger.String(): {
+				{ProductID: fries, ProductName: "Fries", Score: 0.7, Orders: 7},
+				{ProductID: beer, ProductName: "Beer", Score: 0.3, Orders: 3},
+				{ProductID: cake, ProductName: "Cake", Score: 0.2, Orders: 2},
+			},
+			fries.String(): {
+				{ProductID: burger, ProductName: "Burger", Score: 0.6, Orders: 7},
+				{ProductID: beer, ProductName: "Beer", Score: 0.5, Orders: 5},
+				{ProductID: soda, ProductName: "Soda", Score: 0.1, Orders: 1},
+			},
+		},
+		ComputedAt: now,
+	}
+	// Cake is out of stock
+	available := []uuid.UUID{burger, fries, beer, soda}
+
+	menu := BuildMenuRecommendations(stand, []uuid.UUID{burger, fries}, available, DefaultConfig())
+
+	assert.Equal(t, standID, menu.StandID)
+	require.NotNil(t, menu.ComputedAt)
+	assert.Equal(t, now, *menu.ComputedAt)
+
+	// Products in the basket and unavailable products are left out, beer keeps its best score
+	require.Len(t, menu.AlsoBought, 2)
+	assert.Equal(t, beer, menu.AlsoBought[0].ProductID)
+	assert.Equal(t, 0.5, menu.AlsoBought[0].Score)
+	assert.Equal(t, soda, menu.AlsoBought[1].ProductID)
+
+	require.Len(t, menu.TopSellers, 1)
+	assert.Equal(t, beer, menu.TopSellers[0].ProductID)
+}
@@ -0,0 +1,74 @@
+package recommendation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// maxMenuProducts bounds the products a menu can ask recommendations for
+const maxMenuProducts = 50
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the menu recommendations, open to every authenticated user
+// of the festival
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/recommendations", h.GetMenuRecommendations)
+}
+
+// GetMenuRecommendations returns the recommendations to show on a stand menu
+// @Summary Get menu recommendations
+// @Description Get the current top sellers of a stand and the products other attendees bought with the products in the basket. Recommendations are refreshed by the analytics worker; products already in the basket, inactive or out of stock are left out.
+// @Tags recommendations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string true "Stand ID" format(uuid)
+// @Param productId query []string false "Products in the basket" collectionFormat(multi)
+// @Success 200 {object} response.Response{data=MenuRecommendations} "Menu recommendations"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/recommendations [get]
+func (h *Handler) GetMenuRecommendations(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	standID, err := uuid.Parse(c.Query("standId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_STAND_ID", "Invalid stand ID", nil)
+		return
+	}
+
+	rawProductIDs := c.QueryArray("productId")
+	if len(rawProductIDs) > maxMenuProducts {
+		response.BadRequest(c, "TOO_MANY_PRODUCTS", "At most 50 products can be passed", nil)
+		return
+	}
+	productIDs := make([]uuid.UUID, 0, len(rawProductIDs))
+	for _, raw := range rawProductIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_PRODUCT_ID", "Invalid product ID", nil)
+			return
+		}
+		productIDs = append(productIDs, id)
+	}
+
+	recommendations, err := h.service.GetMenuRecommendations(c.Request.Context(), festivalID, standID, productIDs)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, recommendations)
+}
@@ -0,0 +1,72 @@
+package recommendation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config configures how recommendations are computed
+type Config struct {
+	BasketWindow   time.Duration // Paid orders mined for products bought together
+	TrendingWindow time.Duration // Paid orders counted for the current top sellers
+	MinPairOrders  int           // Products bought together fewer times are never recommended
+	MinConfidence  float64       // Minimum share of the orders of X that also contain Y
+	MaxAlsoBought  int           // Recommendations kept per product
+	MaxTopSellers  int           // Top sellers kept per stand
+	CacheTTL       time.Duration // Cached recommendations expire if the worker stops refreshing them
+}
+
+// DefaultConfig returns the default recommendation configuration
+func DefaultConfig() Config {
+	return Config{
+		BasketWindow:   7 * 24 * time.Hour,
+		TrendingWindow: time.Hour,
+		MinPairOrders:  3,
+		MinConfidence:  0.05,
+		MaxAlsoBought:  5,
+		MaxTopSellers:  5,
+		CacheTTL:       2 * time.Hour,
+	}
+}
+
+// BasketLine is a product of a paid order, as retrieved by the repository
+type BasketLine struct {
+	OrderID     uuid.UUID `gorm:"column:order_id"`
+	StandID     uuid.UUID `gorm:"column:stand_id"`
+	ProductID   uuid.UUID `gorm:"column:product_id"`
+	ProductName string    `gorm:"column:product_name"`
+}
+
+// ProductSales is the recent sales of a product, as aggregated by the repository
+type ProductSales struct {
+	StandID     uuid.UUID `gorm:"column:stand_id"`
+	ProductID   uuid.UUID `gorm:"column:product_id"`
+	ProductName string    `gorm:"column:product_name"`
+	Quantity    int64     `gorm:"column:quantity"`
+	Orders      int64     `gorm:"column:orders"`
+}
+
+// Recommendation is a product suggested on a stand menu
+type Recommendation struct {
+	ProductID   uuid.UUID `json:"productId"`
+	ProductName string    `json:"productName"`
+	Score       float64   `json:"score"`  // Confidence for also-bought products, share of recent sales for top sellers
+	Orders      int64     `json:"orders"` // Orders supporting the recommendation
+}
+
+// StandRecommendations is what the analytics worker caches for a stand
+type StandRecommendations struct {
+	StandID    uuid.UUID                   `json:"standId"`
+	TopSellers []Recommendation            `json:"topSellers"`
+	AlsoBought map[string][]Recommendation `json:"alsoBought"` // Keyed by product ID
+	ComputedAt time.Time                   `json:"computedAt"`
+}
+
+// MenuRecommendations is returned to the attendee app when it renders a stand menu
+type MenuRecommendations struct {
+	StandID    uuid.UUID        `json:"standId"`
+	TopSellers []Recommendation `json:"topSellers"`
+	AlsoBought []Recommendation `json:"alsoBought"`           // For the products passed, best first
+	ComputedAt *time.Time       `json:"computedAt,omitempty"` // Nil until the worker computed the stand
+}
@@ -0,0 +1,99 @@
+package recommendation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	GetBasketLines(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]BasketLine, error)
+	GetProductSales(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]ProductSales, error)
+	GetFestivalsWithOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	GetAvailableProducts(ctx context.Context, standID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// GetBasketLines returns the distinct products of each paid order of a festival since
+// the given time
+func (r *repository) GetBasketLines(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]BasketLine, error) {
+	var lines []BasketLine
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.id as order_id,
+			o.stand_id,
+			(item->>'productId')::uuid as product_id,
+			MAX(item->>'productName') as product_name
+		FROM orders o
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		WHERE o.festival_id = ?
+			AND o.status = 'PAID'
+			AND o.created_at >= ?
+		GROUP BY o.id, o.stand_id, (item->>'productId')::uuid`,
+		festivalID, since,
+	).Scan(&lines).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get basket lines: %w", err)
+	}
+	return lines, nil
+}
+
+// GetProductSales returns the quantity sold and the orders of each product of a
+// festival since the given time
+func (r *repository) GetProductSales(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]ProductSales, error) {
+	var sales []ProductSales
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.stand_id,
+			(item->>'productId')::uuid as product_id,
+			MAX(item->>'productName') as product_name,
+			COALESCE(SUM((item->>'quantity')::int), 0) as quantity,
+			COUNT(DISTINCT o.id) as orders
+		FROM orders o
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		WHERE o.festival_id = ?
+			AND o.status = 'PAID'
+			AND o.created_at >= ?
+		GROUP BY o.stand_id, (item->>'productId')::uuid`,
+		festivalID, since,
+	).Scan(&sales).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product sales: %w", err)
+	}
+	return sales, nil
+}
+
+// GetFestivalsWithOrders returns the festivals with paid orders since the given time
+func (r *repository) GetFestivalsWithOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("orders").
+		Distinct("festival_id").
+		Where("status = ? AND created_at >= ?", "PAID", since).
+		Pluck("festival_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festivals with orders: %w", err)
+	}
+	return ids, nil
+}
+
+// GetAvailableProducts returns the products of a stand that can be ordered now
+func (r *repository) GetAvailableProducts(ctx context.Context, standID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("products").
+		Where("stand_id = ? AND status = ? AND (stock IS NULL OR stock > 0)", standID, "ACTIVE").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available products: %w", err)
+	}
+	return ids, nil
+}
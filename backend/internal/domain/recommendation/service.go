@@ -0,0 +1,180 @@
+package recommendation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Service computes product recommendations from paid orders and serves them from Redis
+type Service struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	config      Config
+	now         func() time.Time
+}
+
+// NewService creates a new recommendation service
+func NewService(repo Repository, redisClient *redis.Client, config Config) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// RefreshRecommendations recomputes the recommendations of a festival, or of every
+// festival with recent orders when festivalID is nil
+func (s *Service) RefreshRecommendations(ctx context.Context, festivalID *uuid.UUID) error {
+	if festivalID != nil {
+		return s.Refresh(ctx, *festivalID)
+	}
+	return s.RefreshAll(ctx)
+}
+
+// RefreshAll recomputes the recommendations of every festival with orders in the basket window
+func (s *Service) RefreshAll(ctx context.Context) error {
+	festivalIDs, err := s.repo.GetFestivalsWithOrders(ctx, s.now().Add(-s.config.BasketWindow))
+	if err != nil {
+		return err
+	}
+
+	for _, festivalID := range festivalIDs {
+		if err := s.Refresh(ctx, festivalID); err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to refresh festival recommendations")
+		}
+	}
+	return nil
+}
+
+// Refresh recomputes and stores the recommendations of a festival's stands
+func (s *Service) Refresh(ctx context.Context, festivalID uuid.UUID) error {
+	now := s.now()
+	lines, err := s.repo.GetBasketLines(ctx, festivalID, now.Add(-s.config.BasketWindow))
+	if err != nil {
+		return err
+	}
+	sales, err := s.repo.GetProductSales(ctx, festivalID, now.Add(-s.config.TrendingWindow))
+	if err != nil {
+		return err
+	}
+
+	return s.store(ctx, festivalID, ComputeStandRecommendations(lines, sales, s.config, now))
+}
+
+// GetMenuRecommendations returns the top sellers of a stand and the products bought with
+// the given ones, leaving out the given products and those that cannot be ordered now
+func (s *Service) GetMenuRecommendations(ctx context.Context, festivalID, standID uuid.UUID, productIDs []uuid.UUID) (*MenuRecommendations, error) {
+	stand, err := s.getStand(ctx, festivalID, standID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil {
+		return &MenuRecommendations{StandID: standID, TopSellers: []Recommendation{}, AlsoBought: []Recommendation{}}, nil
+	}
+
+	available, err := s.repo.GetAvailableProducts(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildMenuRecommendations(stand, productIDs, available, s.config), nil
+}
+
+// BuildMenuRecommendations merges the also-bought recommendations of the given products,
+// keeping the best score of each suggested product
+func BuildMenuRecommendations(stand *StandRecommendations, productIDs, available []uuid.UUID, cfg Config) *MenuRecommendations {
+	exclude := make(map[uuid.UUID]bool, len(productIDs))
+	for _, id := range productIDs {
+		exclude[id] = true
+	}
+	orderable := make(map[uuid.UUID]bool, len(available))
+	for _, id := range available {
+		orderable[id] = true
+	}
+	keep := func(r Recommendation) bool {
+		return !exclude[r.ProductID] && orderable[r.ProductID]
+	}
+
+	best := make(map[uuid.UUID]Recommendation)
+	for _, id := range productIDs {
+		for _, r := range stand.AlsoBought[id.String()] {
+			if !keep(r) {
+				continue
+			}
+			if current, ok := best[r.ProductID]; !ok || r.Score > current.Score {
+				best[r.ProductID] = r
+			}
+		}
+	}
+	alsoBought := make([]Recommendation, 0, len(best))
+	for _, r := range best {
+		alsoBought = append(alsoBought, r)
+	}
+	sortRecommendations(alsoBought)
+	if len(alsoBought) > cfg.MaxAlsoBought {
+		alsoBought = alsoBought[:cfg.MaxAlsoBought]
+	}
+
+	topSellers := make([]Recommendation, 0, len(stand.TopSellers))
+	for _, r := range stand.TopSellers {
+		if keep(r) {
+			topSellers = append(topSellers, r)
+		}
+	}
+
+	computedAt := stand.ComputedAt
+	return &MenuRecommendations{
+		StandID:    stand.StandID,
+		TopSellers: topSellers,
+		AlsoBought: alsoBought,
+		ComputedAt: &computedAt,
+	}
+}
+
+// getStand returns the cached recommendations of a stand, or nil when there are none
+func (s *Service) getStand(ctx context.Context, festivalID, standID uuid.UUID) (*StandRecommendations, error) {
+	value, err := s.redisClient.HGet(ctx, s.keyBuilder.StandRecommendationsKey(festivalID), standID.String()).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand recommendations: %w", err)
+	}
+
+	var stand StandRecommendations
+	if err := json.Unmarshal([]byte(value), &stand); err != nil {
+		return nil, fmt.Errorf("failed to decode stand recommendations: %w", err)
+	}
+	return &stand, nil
+}
+
+// store replaces the festival recommendations so stands without orders drop out
+func (s *Service) store(ctx context.Context, festivalID uuid.UUID, stands map[uuid.UUID]*StandRecommendations) error {
+	key := s.keyBuilder.StandRecommendationsKey(festivalID)
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	for standID, stand := range stands {
+		data, err := json.Marshal(stand)
+		if err != nil {
+			return fmt.Errorf("failed to encode stand recommendations: %w", err)
+		}
+		pipe.HSet(ctx, key, standID.String(), data)
+	}
+	pipe.Expire(ctx, key, s.config.CacheTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store recommendations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package reconciliation
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped wallet reconciliation reports, which
+// should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	reconciliations := r.Group("/reconciliations")
+	{
+		reconciliations.GET("", h.List)
+		reconciliations.POST("", h.Run)
+		reconciliations.GET("/:reconciliationId", h.Get)
+	}
+}
+
+// List lists the wallet reconciliations of the festival
+// @Summary List wallet reconciliations
+// @Description List the wallet reconciliation reports of the festival, latest first, without their discrepancies
+// @Tags reconciliations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(BALANCED, DRIFT)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Reconciliation,meta=response.Meta} "Reconciliations"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/reconciliations [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	status := Status(c.Query("status"))
+	switch status {
+	case "", StatusBalanced, StatusDrift:
+	default:
+		response.BadRequest(c, "INVALID_STATUS", "Status must be BALANCED or DRIFT", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	reconciliations, total, err := h.service.List(c.Request.Context(), festivalID, ReconciliationFilter{
+		Status: status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, reconciliations, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Get returns a wallet reconciliation with its discrepancies
+// @Summary Get wallet reconciliation
+// @Description Get a wallet reconciliation report with its itemized discrepancies
+// @Tags reconciliations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param reconciliationId path string true "Reconciliation ID" format(uuid)
+// @Success 200 {object} response.Response{data=Reconciliation} "Reconciliation"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Reconciliation not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/reconciliations/{reconciliationId} [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("reconciliationId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid reconciliation ID", nil)
+		return
+	}
+
+	reconciliation, err := h.service.Get(c.Request.Context(), festivalID, id)
+	if err != nil {
+		if errors.Is(err, ErrReconciliationNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, reconciliation)
+}
+
+// Run reconciles the festival wallets now
+// @Summary Run wallet reconciliation
+// @Description Reconcile the festival wallets with their ledger, Stripe payments and cash top-ups now, outside the nightly run
+// @Tags reconciliations
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 201 {object} response.Response{data=Reconciliation} "Reconciliation"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/reconciliations [post]
+func (h *Handler) Run(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var triggeredBy *uuid.UUID
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		triggeredBy = &id
+	}
+
+	reconciliation, err := h.service.Reconcile(c.Request.Context(), festivalID, triggeredBy)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, reconciliation)
+}
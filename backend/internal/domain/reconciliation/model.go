@@ -0,0 +1,125 @@
+package reconciliation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reconciliation errors
+var (
+	ErrReconciliationNotFound = errors.New("reconciliation not found")
+)
+
+// Config tunes the wallet reconciliation
+type Config struct {
+	Threshold      int64         // Drift in cents above which an alert is fired
+	CompletedFor   time.Duration // How long completed festivals keep being reconciled
+	StripeLookback time.Duration // How far back payment intents are verified against Stripe
+	StripeLimit    int           // Maximum payment intents verified against Stripe per festival and run
+	SettleDelay    time.Duration // Age after which an unsettled payment intent is checked on Stripe
+}
+
+// DefaultConfig returns the reconciliation configuration used by the worker. The
+// Stripe lookback covers the day since the previous nightly run, with some overlap.
+func DefaultConfig() Config {
+	return Config{
+		Threshold:      100,
+		CompletedFor:   30 * 24 * time.Hour,
+		StripeLookback: 26 * time.Hour,
+		StripeLimit:    500,
+		SettleDelay:    time.Hour,
+	}
+}
+
+// Status is the outcome of a reconciliation
+type Status string
+
+const (
+	StatusBalanced Status = "BALANCED" // Drift within the threshold
+	StatusDrift    Status = "DRIFT"    // Drift above the threshold, alert fired
+)
+
+// DiscrepancyKind is what a discrepancy compares
+type DiscrepancyKind string
+
+const (
+	// Wallet balance differs from the sum of its transactions
+	KindWalletLedgerMismatch DiscrepancyKind = "WALLET_LEDGER_MISMATCH"
+	// Succeeded Stripe payment never credited to its wallet
+	KindStripeNotCredited DiscrepancyKind = "STRIPE_NOT_CREDITED"
+	// Stripe payment credited with a different amount
+	KindStripeAmountMismatch DiscrepancyKind = "STRIPE_AMOUNT_MISMATCH"
+	// Stripe top-up without a succeeded payment intent
+	KindStripeTopUpUnmatched DiscrepancyKind = "STRIPE_TOP_UP_UNMATCHED"
+	// Payment intent whose status or captured amount differs on Stripe
+	KindStripeCaptureMismatch DiscrepancyKind = "STRIPE_CAPTURE_MISMATCH"
+	// Cash top-ups of a closed day changed since its Z report
+	KindCashChangedAfterClose DiscrepancyKind = "CASH_CHANGED_AFTER_CLOSE"
+)
+
+// Discrepancy is one itemized difference found by a reconciliation. Amounts are in
+// cents and Difference is Actual minus Expected.
+type Discrepancy struct {
+	Kind       DiscrepancyKind `json:"kind"`
+	WalletID   *uuid.UUID      `json:"walletId,omitempty"`
+	Reference  string          `json:"reference,omitempty"` // Stripe payment intent ID
+	DayCloseID *uuid.UUID      `json:"dayCloseId,omitempty"`
+	Expected   int64           `json:"expected"`
+	Actual     int64           `json:"actual"`
+	Difference int64           `json:"difference"`
+	Detail     string          `json:"detail,omitempty"`
+}
+
+// Totals are the festival-wide amounts compared by a reconciliation, in cents
+type Totals struct {
+	Liabilities    int64 `json:"liabilities"`    // Sum of the wallet balances
+	LedgerBalance  int64 `json:"ledgerBalance"`  // Sum of the wallet transactions
+	StripeCaptured int64 `json:"stripeCaptured"` // Wallet credit of the succeeded Stripe payment intents
+	StripeCredited int64 `json:"stripeCredited"` // Stripe top-ups credited to wallets
+	CashTopUps     int64 `json:"cashTopUps"`     // Cash top-ups at the stands
+}
+
+// Reconciliation is the report of a festival's wallet reconciliation
+type Reconciliation struct {
+	ID               uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID       uuid.UUID     `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Totals           Totals        `json:"totals" gorm:"embedded"`
+	Drift            int64         `json:"drift"`     // Sum of the absolute differences, in cents
+	Threshold        int64         `json:"threshold"` // Threshold applied, in cents
+	Status           Status        `json:"status"`
+	Discrepancies    []Discrepancy `json:"discrepancies,omitempty" gorm:"type:jsonb;serializer:json"` // Omitted from lists
+	DiscrepancyCount int           `json:"discrepancyCount"`
+	StripeChecked    int           `json:"stripeChecked"` // Payment intents verified against Stripe
+	Alerted          bool          `json:"alerted"`
+	TriggeredBy      *uuid.UUID    `json:"triggeredBy,omitempty" gorm:"type:uuid"` // Nil for the nightly run
+	StartedAt        time.Time     `json:"startedAt"`
+	CompletedAt      time.Time     `json:"completedAt"`
+}
+
+func (Reconciliation) TableName() string {
+	return "wallet_reconciliations"
+}
+
+// IntentCheck is a payment intent to verify against Stripe
+type IntentCheck struct {
+	StripeIntentID string
+	WalletID       uuid.UUID
+	Amount         int64
+	Status         string
+}
+
+// ReconciliationFilter filters the listed reconciliations
+type ReconciliationFilter struct {
+	Status Status
+	Offset int
+	Limit  int
+}
+
+// RunResult summarizes a nightly run over all festivals
+type RunResult struct {
+	Festivals int `json:"festivals"`
+	Drifting  int `json:"drifting"`
+	Failed    int `json:"failed"`
+}
@@ -0,0 +1,255 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	ListFestivals(ctx context.Context, completedSince time.Time) ([]uuid.UUID, error)
+	GetTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error)
+	FindWalletMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error)
+	FindStripeMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error)
+	FindCashMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error)
+	ListIntentChecks(ctx context.Context, festivalID uuid.UUID, since, unsettledBefore time.Time, limit int) ([]IntentCheck, error)
+
+	Create(ctx context.Context, reconciliation *Reconciliation) error
+	Get(ctx context.Context, festivalID, id uuid.UUID) (*Reconciliation, error)
+	List(ctx context.Context, festivalID uuid.UUID, filter ReconciliationFilter) ([]Reconciliation, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ledgerStatuses are the transaction statuses that moved a wallet balance: refunded
+// transactions stay in the ledger next to the refund reversing them
+const ledgerStatuses = `('COMPLETED', 'REFUNDED')`
+
+// stripeTopUps are the wallet credits of Stripe payments, referencing their intent
+const stripeTopUps = `t.type = 'TOP_UP' AND t.metadata->>'paymentMethod' = 'stripe'`
+
+// creditedIntents filters out the payments of the entry bundles refunded without being
+// fulfilled, which never credited their wallet, and of the pre-sale top-ups held until
+// the gates open
+const creditedIntents = `NOT EXISTS (
+	SELECT 1 FROM public.bundle_purchases bp
+	WHERE bp.stripe_intent_id = pi.stripe_intent_id AND bp.status = 'FAILED')
+	AND NOT EXISTS (
+	SELECT 1 FROM public.wallet_presales ps
+	WHERE ps.stripe_intent_id = pi.stripe_intent_id AND ps.status <> 'ACTIVATED')`
+
+// ListFestivals returns the festivals with wallets to reconcile: active ones, and
+// completed ones that ended since completedSince, as late refunds still move money
+func (r *repository) ListFestivals(ctx context.Context, completedSince time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT f.id
+		FROM public.festivals f
+		WHERE (f.status = 'ACTIVE' OR (f.status = 'COMPLETED' AND f.end_date >= ?))
+			AND EXISTS (SELECT 1 FROM public.wallets w WHERE w.festival_id = f.id)
+		ORDER BY f.id`,
+		completedSince,
+	).Scan(&ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list festivals to reconcile: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *repository) GetTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error) {
+	var totals Totals
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(w.balance), 0) FROM public.wallets w WHERE w.festival_id = @festival) AS liabilities,
+			(SELECT COALESCE(SUM(t.amount), 0)
+				FROM public.transactions t
+				INNER JOIN public.wallets w ON w.id = t.wallet_id
+				WHERE w.festival_id = @festival AND t.status IN `+ledgerStatuses+`) AS ledger_balance,
+			(SELECT COALESCE(SUM(pi.credit_amount), 0)
+				FROM public.payment_intents pi
+				WHERE pi.festival_id = @festival AND pi.wallet_id <> @nil AND pi.status = 'SUCCEEDED'
+					AND `+creditedIntents+`) AS stripe_captured,
+			(SELECT COALESCE(SUM(t.amount), 0)
+				FROM public.transactions t
+				INNER JOIN public.wallets w ON w.id = t.wallet_id
+				WHERE w.festival_id = @festival AND `+stripeTopUps+` AND t.status IN `+ledgerStatuses+`) AS stripe_credited,
+			(SELECT COALESCE(SUM(t.amount), 0)
+				FROM public.transactions t
+				INNER JOIN public.wallets w ON w.id = t.wallet_id
+				WHERE w.festival_id = @festival AND t.type = 'CASH_IN' AND t.status IN `+ledgerStatuses+`) AS cash_top_ups`,
+		map[string]interface{}{"festival": festivalID, "nil": uuid.Nil},
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation totals: %w", err)
+	}
+	return &totals, nil
+}
+
+// FindWalletMismatches returns the wallets whose balance differs from the sum of their
+// transactions
+func (r *repository) FindWalletMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error) {
+	var discrepancies []Discrepancy
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT 'WALLET_LEDGER_MISMATCH' AS kind, w.id AS wallet_id,
+			COALESCE(SUM(t.amount), 0) AS expected, w.balance AS actual,
+			w.balance - COALESCE(SUM(t.amount), 0) AS difference
+		FROM public.wallets w
+		LEFT JOIN public.transactions t ON t.wallet_id = w.id AND t.status IN `+ledgerStatuses+`
+		WHERE w.festival_id = ?
+		GROUP BY w.id, w.balance
+		HAVING w.balance <> COALESCE(SUM(t.amount), 0)
+		ORDER BY ABS(w.balance - COALESCE(SUM(t.amount), 0)) DESC, w.id`,
+		festivalID,
+	).Scan(&discrepancies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find wallet ledger mismatches: %w", err)
+	}
+	return discrepancies, nil
+}
+
+// FindStripeMismatches returns the succeeded wallet payment intents not credited with
+// their credit amount, the part of an entry bundle not paying for its ticket, and the
+// Stripe top-ups without a succeeded payment intent
+func (r *repository) FindStripeMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error) {
+	var discrepancies []Discrepancy
+	err := r.db.WithContext(ctx).Raw(`
+		WITH credits AS (
+			SELECT t.reference, t.wallet_id, SUM(t.amount) AS amount
+			FROM public.transactions t
+			INNER JOIN public.wallets w ON w.id = t.wallet_id
+			WHERE w.festival_id = @festival AND `+stripeTopUps+` AND t.status IN `+ledgerStatuses+`
+			GROUP BY t.reference, t.wallet_id
+		), intents AS (
+			SELECT pi.stripe_intent_id, pi.wallet_id, pi.credit_amount AS amount
+			FROM public.payment_intents pi
+			WHERE pi.festival_id = @festival AND pi.wallet_id <> @nil AND pi.status = 'SUCCEEDED'
+				AND `+creditedIntents+`
+		)
+		SELECT CASE WHEN c.reference IS NULL THEN 'STRIPE_NOT_CREDITED' ELSE 'STRIPE_AMOUNT_MISMATCH' END AS kind,
+			i.wallet_id, i.stripe_intent_id AS reference,
+			i.amount AS expected, COALESCE(c.amount, 0) AS actual,
+			COALESCE(c.amount, 0) - i.amount AS difference
+		FROM intents i
+		LEFT JOIN credits c ON c.reference = i.stripe_intent_id AND c.wallet_id = i.wallet_id
+		WHERE c.reference IS NULL OR c.amount <> i.amount
+		UNION ALL
+		SELECT 'STRIPE_TOP_UP_UNMATCHED' AS kind, c.wallet_id, c.reference,
+			0 AS expected, c.amount AS actual, c.amount AS difference
+		FROM credits c
+		WHERE NOT EXISTS (
+			SELECT 1 FROM intents i WHERE i.stripe_intent_id = c.reference AND i.wallet_id = c.wallet_id
+		)
+		ORDER BY kind, reference`,
+		map[string]interface{}{"festival": festivalID, "nil": uuid.Nil},
+	).Scan(&discrepancies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Stripe mismatches: %w", err)
+	}
+	return discrepancies, nil
+}
+
+// FindCashMismatches returns the day closes whose Z report cash top-ups differ from the
+// cash top-ups now recorded over the closed period, e.g. after a late offline sync
+func (r *repository) FindCashMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error) {
+	var discrepancies []Discrepancy
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT 'CASH_CHANGED_AFTER_CLOSE' AS kind, c.day_close_id,
+			c.expected, c.actual, c.actual - c.expected AS difference,
+			'Z report ' || c.report_number AS detail
+		FROM (
+			SELECT dc.id AS day_close_id, dc.report_number, dc.period_start,
+				COALESCE(CAST(dc.report->>'cashIn' AS BIGINT), 0) AS expected,
+				(SELECT COALESCE(SUM(t.amount), 0)
+					FROM public.transactions t
+					INNER JOIN public.wallets w ON w.id = t.wallet_id
+					WHERE w.festival_id = dc.festival_id AND t.type = 'CASH_IN' AND t.status = 'COMPLETED'
+						AND t.stand_id IS NOT NULL AND (dc.stand_id IS NULL OR t.stand_id = dc.stand_id)
+						AND t.created_at >= dc.period_start AND t.created_at < dc.period_end) AS actual
+			FROM public.day_closes dc
+			WHERE dc.festival_id = ?
+		) c
+		WHERE c.actual <> c.expected
+		ORDER BY c.period_start, c.day_close_id`,
+		festivalID,
+	).Scan(&discrepancies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cash mismatches: %w", err)
+	}
+	return discrepancies, nil
+}
+
+// ListIntentChecks returns the wallet payment intents to verify against Stripe: the
+// ones succeeded since since, and the ones still unsettled locally since before
+// unsettledBefore, which may have missed their webhook
+func (r *repository) ListIntentChecks(ctx context.Context, festivalID uuid.UUID, since, unsettledBefore time.Time, limit int) ([]IntentCheck, error) {
+	var checks []IntentCheck
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT pi.stripe_intent_id, pi.wallet_id, pi.amount, pi.status
+		FROM public.payment_intents pi
+		WHERE pi.festival_id = ? AND pi.wallet_id <> ?
+			AND (
+				(pi.status = 'SUCCEEDED' AND pi.completed_at >= ?)
+				OR (pi.status IN ('PENDING', 'PROCESSING', 'REQUIRES_ACTION') AND pi.created_at >= ? AND pi.created_at < ?)
+			)
+		ORDER BY pi.created_at DESC
+		LIMIT ?`,
+		festivalID, uuid.Nil, since, since, unsettledBefore, limit,
+	).Scan(&checks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment intents to verify: %w", err)
+	}
+	return checks, nil
+}
+
+func (r *repository) Create(ctx context.Context, reconciliation *Reconciliation) error {
+	if err := r.db.WithContext(ctx).Create(reconciliation).Error; err != nil {
+		return fmt.Errorf("failed to create reconciliation: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Reconciliation, error) {
+	var reconciliation Reconciliation
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&reconciliation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reconciliation: %w", err)
+	}
+	return &reconciliation, nil
+}
+
+// List returns the reconciliations of a festival without their discrepancies, latest first
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, filter ReconciliationFilter) ([]Reconciliation, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Reconciliation{}).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count reconciliations: %w", err)
+	}
+
+	var reconciliations []Reconciliation
+	err := query.Omit("discrepancies").
+		Order("started_at DESC").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&reconciliations).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reconciliations: %w", err)
+	}
+	return reconciliations, total, nil
+}
@@ -0,0 +1,72 @@
+package reconciliation
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) ListFestivals(ctx context.Context, completedSince time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, completedSince)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetTotals(ctx context.Context, festivalID uuid.UUID) (*Totals, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Totals), args.Error(1)
+}
+
+func (m *MockRepository) FindWalletMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Discrepancy), args.Error(1)
+}
+
+func (m *MockRepository) FindStripeMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Discrepancy), args.Error(1)
+}
+
+func (m *MockRepository) FindCashMismatches(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Discrepancy), args.Error(1)
+}
+
+func (m *MockRepository) ListIntentChecks(ctx context.Context, festivalID uuid.UUID, since, unsettledBefore time.Time, limit int) ([]IntentCheck, error) {
+	args := m.Called(ctx, festivalID, since, unsettledBefore, limit)
+	return args.Get(0).([]IntentCheck), args.Error(1)
+}
+
+func (m *MockRepository) Create(ctx context.Context, reconciliation *Reconciliation) error {
+	args := m.Called(ctx, reconciliation)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Reconciliation, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Reconciliation), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context, festivalID uuid.UUID, filter ReconciliationFilter) ([]Reconciliation, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Reconciliation), args.Get(1).(int64), args.Error(2)
+}
@@ -0,0 +1,322 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/rs/zerolog/log"
+	"github.com/stripe/stripe-go/v76"
+)
+
+// TypeReconcileWallets is the nightly worker task reconciling the wallets of every festival
+const TypeReconcileWallets = "wallet:reconcile"
+
+// driftAlertName is the name of the alert fired when a festival drifts
+const driftAlertName = "WalletReconciliationDrift"
+
+// StripeVerifier reads payment intents from Stripe; satisfied by payment.StripeClient
+type StripeVerifier interface {
+	GetPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
+}
+
+// Alerter fires alerts to the on-call; satisfied by monitoring.WebhookAlerter
+type Alerter interface {
+	Fire(ctx context.Context, alert *monitoring.Alert) error
+}
+
+// Service reconciles the wallet liabilities of festivals with their ledger, Stripe
+// payments and cash top-ups
+type Service struct {
+	repo     Repository
+	verifier StripeVerifier
+	alerter  Alerter
+	jobs     realtime.JobBroadcaster
+	config   Config
+	now      func() time.Time
+}
+
+// NewService creates a new reconciliation service
+func NewService(repo Repository, config Config) *Service {
+	return &Service{
+		repo:   repo,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// SetStripeVerifier verifies recent payment intents against Stripe itself
+func (s *Service) SetStripeVerifier(verifier StripeVerifier) {
+	s.verifier = verifier
+}
+
+// SetAlerter fires an alert for each reconciliation drifting above the threshold
+func (s *Service) SetAlerter(alerter Alerter) {
+	s.alerter = alerter
+}
+
+// SetJobBroadcaster reports the progress of reconciliations to the dashboards
+func (s *Service) SetJobBroadcaster(jobs realtime.JobBroadcaster) {
+	s.jobs = jobs
+}
+
+// HandleReconcileWallets handles the nightly reconciliation of all festivals. Drift is
+// reported through the reports and alerts, not by failing the task, so a retry does
+// not reconcile the festivals twice.
+func (s *Service) HandleReconcileWallets(ctx context.Context, t *asynq.Task) error {
+	result, err := s.ReconcileAll(ctx)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Int("festivals", result.Festivals).
+		Int("drifting", result.Drifting).
+		Int("failed", result.Failed).
+		Msg("Reconciled festival wallets")
+	return nil
+}
+
+// ReconcileAll reconciles every active festival, and completed festivals for a while
+// after their end. A festival failing to reconcile does not stop the others.
+func (s *Service) ReconcileAll(ctx context.Context) (*RunResult, error) {
+	festivalIDs, err := s.repo.ListFestivals(ctx, s.now().Add(-s.config.CompletedFor))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RunResult{}
+	for _, festivalID := range festivalIDs {
+		reconciliation, err := s.Reconcile(ctx, festivalID, nil)
+		if err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to reconcile festival wallets")
+			result.Failed++
+			continue
+		}
+		result.Festivals++
+		if reconciliation.Status == StatusDrift {
+			result.Drifting++
+		}
+	}
+	return result, nil
+}
+
+// Reconcile compares the wallet liabilities of a festival with its ledger, Stripe
+// payments and cash top-ups, and records the report. triggeredBy is nil for the
+// nightly run.
+func (s *Service) Reconcile(ctx context.Context, festivalID uuid.UUID, triggeredBy *uuid.UUID) (*Reconciliation, error) {
+	id := uuid.New()
+	job := realtime.TrackJob(s.jobs, festivalID.String(), id.String(), realtime.JobKindReconciliation, "Wallet reconciliation")
+	job.Started(ctx)
+
+	reconciliation, err := s.reconcile(ctx, id, festivalID, triggeredBy, job)
+	if err != nil {
+		job.Failed(ctx, err)
+		return nil, err
+	}
+	job.Completed(ctx, fmt.Sprintf("/api/v1/festivals/%s/reconciliations/%s", festivalID, id))
+	return reconciliation, nil
+}
+
+// reconcile runs the checks of a reconciliation, reporting each one done to job
+func (s *Service) reconcile(ctx context.Context, id, festivalID uuid.UUID, triggeredBy *uuid.UUID, job *realtime.JobTracker) (*Reconciliation, error) {
+	const steps = 5
+	startedAt := s.now()
+
+	totals, err := s.repo.GetTotals(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	job.Progress(ctx, 1, steps)
+
+	var discrepancies []Discrepancy
+	for i, find := range []func(context.Context, uuid.UUID) ([]Discrepancy, error){
+		s.repo.FindWalletMismatches,
+		s.repo.FindStripeMismatches,
+		s.repo.FindCashMismatches,
+	} {
+		found, err := find(ctx, festivalID)
+		if err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, found...)
+		job.Progress(ctx, i+2, steps)
+	}
+
+	found, checked, err := s.verifyStripe(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	discrepancies = append(discrepancies, found...)
+
+	reconciliation := Build(festivalID, *totals, discrepancies, s.config.Threshold)
+	reconciliation.ID = id
+	reconciliation.StripeChecked = checked
+	reconciliation.TriggeredBy = triggeredBy
+	reconciliation.StartedAt = startedAt
+	reconciliation.CompletedAt = s.now()
+
+	if reconciliation.Status == StatusDrift {
+		log.Error().
+			Str("festival_id", festivalID.String()).
+			Int64("drift", reconciliation.Drift).
+			Int64("threshold", reconciliation.Threshold).
+			Int("discrepancies", reconciliation.DiscrepancyCount).
+			Msg("Wallet reconciliation drift above threshold")
+		reconciliation.Alerted = s.alert(ctx, reconciliation)
+	}
+
+	if err := s.repo.Create(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+// Get returns a reconciliation report with its discrepancies
+func (s *Service) Get(ctx context.Context, festivalID, id uuid.UUID) (*Reconciliation, error) {
+	reconciliation, err := s.repo.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if reconciliation == nil {
+		return nil, ErrReconciliationNotFound
+	}
+	return reconciliation, nil
+}
+
+// List returns the reconciliations of a festival, latest first
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, filter ReconciliationFilter) ([]Reconciliation, int64, error) {
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.repo.List(ctx, festivalID, filter)
+}
+
+// Build computes the drift and status of a reconciliation from its discrepancies. The
+// drift adds up the absolute differences, so opposite errors do not cancel out.
+func Build(festivalID uuid.UUID, totals Totals, discrepancies []Discrepancy, threshold int64) *Reconciliation {
+	if discrepancies == nil {
+		discrepancies = []Discrepancy{}
+	}
+
+	var drift int64
+	for _, d := range discrepancies {
+		if d.Difference < 0 {
+			drift -= d.Difference
+		} else {
+			drift += d.Difference
+		}
+	}
+
+	status := StatusBalanced
+	if drift > threshold {
+		status = StatusDrift
+	}
+
+	return &Reconciliation{
+		FestivalID:       festivalID,
+		Totals:           totals,
+		Drift:            drift,
+		Threshold:        threshold,
+		Status:           status,
+		Discrepancies:    discrepancies,
+		DiscrepancyCount: len(discrepancies),
+	}
+}
+
+// verifyStripe compares the recent and unsettled payment intents of a festival with
+// Stripe, returning the mismatches and how many intents were verified. Intents Stripe
+// fails to return are skipped until the next run.
+func (s *Service) verifyStripe(ctx context.Context, festivalID uuid.UUID) ([]Discrepancy, int, error) {
+	if s.verifier == nil {
+		return nil, 0, nil
+	}
+
+	now := s.now()
+	checks, err := s.repo.ListIntentChecks(ctx, festivalID, now.Add(-s.config.StripeLookback), now.Add(-s.config.SettleDelay), s.config.StripeLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var discrepancies []Discrepancy
+	checked := 0
+	for _, check := range checks {
+		remote, err := s.verifier.GetPaymentIntent(ctx, check.StripeIntentID)
+		if err != nil {
+			log.Warn().Err(err).Str("payment_intent", check.StripeIntentID).Msg("Failed to verify payment intent against Stripe")
+			continue
+		}
+		checked++
+		if d := compareIntent(check, remote); d != nil {
+			discrepancies = append(discrepancies, *d)
+		}
+	}
+	return discrepancies, checked, nil
+}
+
+// compareIntent compares the amount captured for a payment intent locally with the
+// amount Stripe received, returning nil when they match
+func compareIntent(check IntentCheck, remote *stripe.PaymentIntent) *Discrepancy {
+	var expected int64
+	if remote.Status == stripe.PaymentIntentStatusSucceeded {
+		expected = remote.AmountReceived
+	}
+	var actual int64
+	if check.Status == "SUCCEEDED" {
+		actual = check.Amount
+	}
+	if expected == actual {
+		return nil
+	}
+
+	walletID := check.WalletID
+	return &Discrepancy{
+		Kind:       KindStripeCaptureMismatch,
+		WalletID:   &walletID,
+		Reference:  check.StripeIntentID,
+		Expected:   expected,
+		Actual:     actual,
+		Difference: actual - expected,
+		Detail:     fmt.Sprintf("%s locally, %s on Stripe", check.Status, remote.Status),
+	}
+}
+
+// alert fires the drift alert of a reconciliation, reporting whether it was sent
+func (s *Service) alert(ctx context.Context, reconciliation *Reconciliation) bool {
+	if s.alerter == nil {
+		return false
+	}
+
+	festivalID := reconciliation.FestivalID.String()
+	err := s.alerter.Fire(ctx, &monitoring.Alert{
+		Name:     driftAlertName,
+		Severity: monitoring.SeverityCritical,
+		State:    monitoring.AlertStateFiring,
+		Labels: map[string]string{
+			"alertname":   driftAlertName,
+			"severity":    string(monitoring.SeverityCritical),
+			"team":        "business",
+			"category":    "wallets",
+			"festival_id": festivalID,
+		},
+		Annotations: map[string]string{
+			"summary": "Wallet reconciliation drift above threshold",
+			"description": fmt.Sprintf("Festival %s: drift %d cents over %d discrepancies, threshold %d cents (reconciliation %s)",
+				festivalID, reconciliation.Drift, reconciliation.DiscrepancyCount, reconciliation.Threshold, reconciliation.ID),
+		},
+		Value:     float64(reconciliation.Drift),
+		Threshold: float64(reconciliation.Threshold),
+		StartsAt:  reconciliation.StartedAt,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("festival_id", festivalID).Msg("Failed to fire wallet reconciliation alert")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+)
+
+type fakeVerifier struct {
+	intents map[string]*stripe.PaymentIntent
+}
+
+func (v *fakeVerifier) GetPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	pi, ok := v.intents[paymentIntentID]
+	if !ok {
+		return nil, errors.New("no such payment_intent")
+	}
+	return pi, nil
+}
+
+type fakeAlerter struct {
+	alerts []*monitoring.Alert
+}
+
+func (a *fakeAlerter) Fire(ctx context.Context, alert *monitoring.Alert) error {
+	a.alerts = append(a.alerts, alert)
+	return nil
+}
+
+func newTestService(repo Repository, verifier StripeVerifier, alerter Alerter) *Service {
+	service := NewService(repo, DefaultConfig())
+	service.SetStripeVerifier(verifier)
+	service.SetAlerter(alerter)
+	now := time.Date(2026, 7, 19, 3, 30, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestBuild(t *testing.T) {
+	festivalID := uuid.New()
+
+	balanced := Build(festivalID, Totals{Liabilities: 5000, LedgerBalance: 5000}, nil, 100)
+	assert.Equal(t, StatusBalanced, balanced.Status)
+	assert.Equal(t, int64(0), balanced.Drift)
+	assert.NotNil(t, balanced.Discrepancies)
+
+	// Opposite differences add up instead of cancelling out
+	drifting := Build(festivalID, Totals{}, []Discrepancy{
+		{Kind: KindWalletLedgerMismatch, Expected: 1000, Actual: 1080, Difference: 80},
+		{Kind: KindCashChangedAfterClose, Expected: 2000, Actual: 1930, Difference: -70},
+	}, 100)
+	assert.Equal(t, StatusDrift, drifting.Status)
+	assert.Equal(t, int64(150), drifting.Drift)
+	assert.Equal(t, 2, drifting.DiscrepancyCount)
+
+	atThreshold := Build(festivalID, Totals{}, []Discrepancy{{Difference: 100}}, 100)
+	assert.Equal(t, StatusBalanced, atThreshold.Status)
+}
+
+func TestReconcile_DriftFiresAlert(t *testing.T) {
+	festivalID := uuid.New()
+	walletID := uuid.New()
+	totals := Totals{Liabilities: 12500, LedgerBalance: 10000, StripeCaptured: 7500, StripeCredited: 5000, CashTopUps: 5000}
+	mockRepo := NewMockRepository()
+	verifier := &fakeVerifier{intents: map[string]*stripe.PaymentIntent{
+		"pi_ok":           {Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 5000},
+		"pi_webhook_lost": {Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 2000},
+	}}
+	alerter := &fakeAlerter{}
+	service := newTestService(mockRepo, verifier, alerter)
+	staffID := uuid.New()
+
+	mockRepo.On("GetTotals", mock.Anything, festivalID).Return(&totals, nil)
+	mockRepo.On("FindWalletMismatches", mock.Anything, festivalID).Return([]Discrepancy{
+		{Kind: KindWalletLedgerMismatch, WalletID: &walletID, Expected: 2500, Actual: 5000, Difference: 2500},
+	}, nil)
+	mockRepo.On("FindStripeMismatches", mock.Anything, festivalID).Return([]Discrepancy{
+		{Kind: KindStripeNotCredited, WalletID: &walletID, Reference: "pi_missed", Expected: 2500, Difference: -2500},
+	}, nil)
+	mockRepo.On("FindCashMismatches", mock.Anything, festivalID).Return([]Discrepancy(nil), nil)
+	mockRepo.On("ListIntentChecks", mock.Anything, festivalID, service.now().Add(-26*time.Hour), service.now().Add(-time.Hour), 500).Return([]IntentCheck{
+		{StripeIntentID: "pi_ok", WalletID: walletID, Amount: 5000, Status: "SUCCEEDED"},
+		{StripeIntentID: "pi_webhook_lost", WalletID: walletID, Amount: 2000, Status: "PROCESSING"},
+		{StripeIntentID: "pi_unreachable", WalletID: walletID, Amount: 1000, Status: "SUCCEEDED"},
+	}, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(r *Reconciliation) bool {
+		return r.FestivalID == festivalID && r.Status == StatusDrift
+	})).Return(nil).Once()
+
+	reconciliation, err := service.Reconcile(context.Background(), festivalID, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDrift, reconciliation.Status)
+	assert.Equal(t, int64(7000), reconciliation.Drift)
+	assert.Equal(t, 3, reconciliation.DiscrepancyCount)
+	assert.Equal(t, 2, reconciliation.StripeChecked)
+	assert.True(t, reconciliation.Alerted)
+	assert.Equal(t, &staffID, reconciliation.TriggeredBy)
+	assert.Equal(t, totals, reconciliation.Totals)
+
+	capture := reconciliation.Discrepancies[2]
+	assert.Equal(t, KindStripeCaptureMismatch, capture.Kind)
+	assert.Equal(t, "pi_webhook_lost", capture.Reference)
+	assert.Equal(t, int64(2000), capture.Expected)
+	assert.Equal(t, int64(-2000), capture.Difference)
+
+	require.Len(t, alerter.alerts, 1)
+	alert := alerter.alerts[0]
+	assert.Equal(t, "WalletReconciliationDrift", alert.Name)
+	assert.Equal(t, monitoring.SeverityCritical, alert.Severity)
+	assert.Equal(t, festivalID.String(), alert.Labels["festival_id"])
+	assert.Equal(t, float64(7000), alert.Value)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReconcileAll_ContinuesAfterFailure(t *testing.T) {
+	failing := uuid.New()
+	balanced := uuid.New()
+	mockRepo := NewMockRepository()
+	alerter := &fakeAlerter{}
+	service := newTestService(mockRepo, nil, alerter)
+
+	mockRepo.On("ListFestivals", mock.Anything, service.now().Add(-30*24*time.Hour)).Return([]uuid.UUID{failing, balanced}, nil)
+	mockRepo.On("GetTotals", mock.Anything, failing).Return(nil, errors.New("connection reset"))
+	mockRepo.On("GetTotals", mock.Anything, balanced).Return(&Totals{Liabilities: 5000, LedgerBalance: 5000}, nil)
+	mockRepo.On("FindWalletMismatches", mock.Anything, balanced).Return([]Discrepancy(nil), nil)
+	mockRepo.On("FindStripeMismatches", mock.Anything, balanced).Return([]Discrepancy(nil), nil)
+	mockRepo.On("FindCashMismatches", mock.Anything, balanced).Return([]Discrepancy(nil), nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(r *Reconciliation) bool {
+		return r.FestivalID == balanced && r.TriggeredBy == nil && r.Status == StatusBalanced
+	})).Return(nil).Once()
+
+	result, err := service.ReconcileAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &RunResult{Festivals: 1, Failed: 1}, result)
+	assert.Empty(t, alerter.alerts)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCompareIntent(t *testing.T) {
+	walletID := uuid.New()
+	succeeded := IntentCheck{StripeIntentID: "pi_1", WalletID: walletID, Amount: 2500, Status: "SUCCEEDED"}
+
+	assert.Nil(t, compareIntent(succeeded, &stripe.PaymentIntent{Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 2500}))
+
+	partial := compareIntent(succeeded, &stripe.PaymentIntent{Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 2000})
+	require.NotNil(t, partial)
+	assert.Equal(t, int64(500), partial.Difference)
+
+	canceled := compareIntent(succeeded, &stripe.PaymentIntent{Status: stripe.PaymentIntentStatusCanceled})
+	require.NotNil(t, canceled)
+	assert.Equal(t, int64(0), canceled.Expected)
+	assert.Equal(t, "SUCCEEDED locally, canceled on Stripe", canceled.Detail)
+
+	pending := IntentCheck{StripeIntentID: "pi_2", WalletID: walletID, Amount: 2500, Status: "PENDING"}
+	assert.Nil(t, compareIntent(pending, &stripe.PaymentIntent{Status: stripe.PaymentIntentStatusRequiresPaymentMethod}))
+}
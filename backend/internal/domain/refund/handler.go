@@ -34,6 +34,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	festivals := r.Group("/festivals")
 	{
 		festivals.GET("/:id/refunds", h.GetFestivalRefunds)
+		festivals.GET("/:id/refunds/split", h.GetSplitSummary)
 	}
 
 	// Admin refund management routes
@@ -75,7 +76,7 @@ func (h *Handler) RequestRefund(c *gin.Context) {
 
 	var input CreateRefundInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -185,6 +186,34 @@ func (h *Handler) GetFestivalRefunds(c *gin.Context) {
 	})
 }
 
+// GetSplitSummary handles GET /festivals/:id/refunds/split - Card and cash totals of festival refunds (admin)
+// @Summary Get festival refund split
+// @Description Get what the refunds of a festival pay back to cards and in cash, by status, so that cash desks know how much cash to hand back (admin only)
+// @Tags refunds
+// @Produce json
+// @Param id path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]SplitSummary} "Split by status"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{id}/refunds/split [get]
+func (h *Handler) GetSplitSummary(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	summaries, err := h.service.GetSplitSummary(c.Request.Context(), festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, summaries)
+}
+
 // GetRefund handles GET /refunds/:id - Get a specific refund request
 // @Summary Get refund by ID
 // @Description Get details of a specific refund request
@@ -300,7 +329,7 @@ func (h *Handler) RejectRefund(c *gin.Context) {
 
 // ProcessRefund handles POST /refunds/:id/process - Process an approved refund (admin)
 // @Summary Process refund
-// @Description Process an approved refund (admin only)
+// @Description Process an approved refund (admin only). The refund is split between the card and cash in proportion to the top-ups of the wallet: the card part goes back to the card, and the cash part is paid with the payment method, cash or bank_transfer.
 // @Tags refunds
 // @Accept json
 // @Produce json
@@ -328,7 +357,7 @@ func (h *Handler) ProcessRefund(c *gin.Context) {
 
 	var input ProcessRefundInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -46,11 +46,13 @@ type RefundRequest struct {
 	Reason        string       `json:"reason" gorm:"type:text"`                   // User's reason for refund
 	BankDetails   BankDetails  `json:"bankDetails" gorm:"embedded"`               // Bank account details
 	Status        RefundStatus `json:"status" gorm:"default:'PENDING';not null"`  // Current status
-	PaymentMethod string       `json:"paymentMethod" gorm:"default:'bank_transfer'"` // stripe, bank_transfer
+	PaymentMethod string       `json:"paymentMethod" gorm:"default:'bank_transfer'"` // stripe, bank_transfer, cash
 	StripeRefundID string      `json:"stripeRefundId,omitempty" gorm:"column:stripe_refund_id"` // Stripe refund ID if applicable
 	ProcessedBy   *uuid.UUID   `json:"processedBy,omitempty" gorm:"type:uuid"`    // Admin who processed the refund
 	ProcessedAt   *time.Time   `json:"processedAt,omitempty"`                     // When the refund was processed
 	RejectionNote string       `json:"rejectionNote,omitempty" gorm:"type:text"`  // Reason for rejection
+	CardAmount    int64        `json:"cardAmount" gorm:"default:0"`               // Part of the net amount going back to the card
+	CashAmount    int64        `json:"cashAmount" gorm:"default:0"`               // Part of the net amount handed back at the cash desk
 	CreatedAt     time.Time    `json:"createdAt"`
 	UpdatedAt     time.Time    `json:"updatedAt"`
 }
@@ -77,12 +79,36 @@ type RejectRefundInput struct {
 	Reason string `json:"reason" binding:"required"`
 }
 
-// ProcessRefundInput represents the input for processing a refund
+// ProcessRefundInput represents the input for processing a refund. The card part of
+// the refund always goes back to the card; PaymentMethod is how the cash part is paid.
 type ProcessRefundInput struct {
-	PaymentMethod  string `json:"paymentMethod" binding:"required,oneof=stripe bank_transfer"`
+	PaymentMethod  string `json:"paymentMethod" binding:"required,oneof=stripe bank_transfer cash"`
 	StripeRefundID string `json:"stripeRefundId,omitempty"`
 }
 
+// Funding is what a wallet was topped up with, by source, less what refunds already
+// paid back to that source
+type Funding struct {
+	Card int64 // Online and card top-ups
+	Cash int64 // Cash top-ups at the booths
+}
+
+// Split routes a refund back to the sources of the wallet
+type Split struct {
+	Card int64 `json:"card"` // Goes back to the card
+	Cash int64 `json:"cash"` // Handed back at the cash desk, or by bank transfer
+}
+
+// SplitSummary totals the split of the refunds of a festival in one status
+type SplitSummary struct {
+	Status      RefundStatus `json:"status"`
+	Count       int64        `json:"count"`
+	CardAmount  int64        `json:"cardAmount"`
+	CardDisplay string       `json:"cardDisplay"`
+	CashAmount  int64        `json:"cashAmount"`
+	CashDisplay string       `json:"cashDisplay"`
+}
+
 // RefundResponse represents the API response for a refund request
 type RefundResponse struct {
 	ID            uuid.UUID    `json:"id"`
@@ -102,6 +128,10 @@ type RefundResponse struct {
 	ProcessedBy   *uuid.UUID   `json:"processedBy,omitempty"`
 	ProcessedAt   *string      `json:"processedAt,omitempty"`
 	RejectionNote string       `json:"rejectionNote,omitempty"`
+	CardAmount    int64        `json:"cardAmount"`
+	CardDisplay   string       `json:"cardDisplay"`
+	CashAmount    int64        `json:"cashAmount"`
+	CashDisplay   string       `json:"cashDisplay"`
 	CreatedAt     string       `json:"createdAt"`
 	UpdatedAt     string       `json:"updatedAt"`
 }
@@ -125,6 +155,10 @@ func (r *RefundRequest) ToResponse() RefundResponse {
 		PaymentMethod: r.PaymentMethod,
 		ProcessedBy:   r.ProcessedBy,
 		RejectionNote: r.RejectionNote,
+		CardAmount:    r.CardAmount,
+		CardDisplay:   formatCents(r.CardAmount),
+		CashAmount:    r.CashAmount,
+		CashDisplay:   formatCents(r.CashAmount),
 		CreatedAt:     r.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:     r.UpdatedAt.Format(time.RFC3339),
 	}
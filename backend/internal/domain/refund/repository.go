@@ -36,6 +36,13 @@ type Repository interface {
 
 	// Update updates a refund request
 	Update(ctx context.Context, refund *RefundRequest) error
+
+	// GetFunding totals the top-ups of a wallet, and of the wallets merged into it, by
+	// source, less the completed refunds paid back to each source
+	GetFunding(ctx context.Context, walletID uuid.UUID) (*Funding, error)
+
+	// GetSplitSummary totals the split of the refunds of a festival by status
+	GetSplitSummary(ctx context.Context, festivalID uuid.UUID) ([]SplitSummary, error)
 }
 
 type repository struct {
@@ -166,3 +173,51 @@ func (r *repository) GetRefundsByWallet(ctx context.Context, walletID uuid.UUID)
 func (r *repository) Update(ctx context.Context, refund *RefundRequest) error {
 	return r.db.WithContext(ctx).Save(refund).Error
 }
+
+func (r *repository) GetFunding(ctx context.Context, walletID uuid.UUID) (*Funding, error) {
+	var funding Funding
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE funded_wallets AS (
+			SELECT id FROM wallets WHERE id = @wallet
+			UNION
+			SELECT w.id FROM wallets w JOIN funded_wallets f ON w.merged_into_id = f.id
+		),
+		topups AS (
+			SELECT
+				COALESCE(SUM(amount) FILTER (
+					WHERE type = 'TOP_UP' AND COALESCE(metadata->>'paymentMethod', '') <> 'cash'
+				), 0) AS card,
+				COALESCE(SUM(amount) FILTER (
+					WHERE type = 'CASH_IN' OR (type = 'TOP_UP' AND metadata->>'paymentMethod' = 'cash')
+				), 0) AS cash
+			FROM transactions
+			WHERE wallet_id IN (SELECT id FROM funded_wallets) AND status = 'COMPLETED'
+		),
+		refunded AS (
+			SELECT COALESCE(SUM(card_amount), 0) AS card, COALESCE(SUM(cash_amount), 0) AS cash
+			FROM refund_requests
+			WHERE wallet_id IN (SELECT id FROM funded_wallets) AND status = 'COMPLETED'
+		)
+		SELECT topups.card - refunded.card AS card, topups.cash - refunded.cash AS cash
+		FROM topups, refunded`,
+		map[string]interface{}{"wallet": walletID},
+	).Scan(&funding).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet funding: %w", err)
+	}
+	return &funding, nil
+}
+
+func (r *repository) GetSplitSummary(ctx context.Context, festivalID uuid.UUID) ([]SplitSummary, error) {
+	var summaries []SplitSummary
+	err := r.db.WithContext(ctx).Model(&RefundRequest{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(card_amount), 0) AS card_amount, COALESCE(SUM(cash_amount), 0) AS cash_amount").
+		Where("festival_id = ?", festivalID).
+		Group("status").
+		Order("status").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund split summary: %w", err)
+	}
+	return summaries, nil
+}
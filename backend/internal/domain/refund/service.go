@@ -10,11 +10,17 @@ import (
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
 )
 
+// ActivityRecorder records refund activity in the organizer feed; satisfied by activity.Service
+type ActivityRecorder interface {
+	RecordRefund(ctx context.Context, festivalID, refundID uuid.UUID, status string, amount int64, actorID *uuid.UUID)
+}
+
 // Service handles refund business logic
 type Service struct {
 	repo          Repository
 	walletRepo    wallet.Repository
 	refundConfigs map[uuid.UUID]FestivalRefundConfig // Festival-specific configs
+	activity      ActivityRecorder
 }
 
 // NewService creates a new refund service
@@ -26,6 +32,11 @@ func NewService(repo Repository, walletRepo wallet.Repository) *Service {
 	}
 }
 
+// SetActivityRecorder reports refund status changes to the activity feed
+func (s *Service) SetActivityRecorder(recorder ActivityRecorder) {
+	s.activity = recorder
+}
+
 // SetFestivalConfig sets the refund configuration for a festival
 func (s *Service) SetFestivalConfig(festivalID uuid.UUID, config FestivalRefundConfig) {
 	s.refundConfigs[festivalID] = config
@@ -50,8 +61,8 @@ func (s *Service) RequestRefund(ctx context.Context, userID uuid.UUID, input Cre
 		return nil, errors.ErrWalletNotFound
 	}
 
-	// Verify wallet belongs to user
-	if w.UserID != userID {
+	// Verify wallet belongs to user; anonymous wallets must be claimed first
+	if !w.IsOwnedBy(userID) {
 		return nil, errors.ErrForbidden
 	}
 
@@ -90,6 +101,12 @@ func (s *Service) RequestRefund(ctx context.Context, userID uuid.UUID, input Cre
 	// Calculate refund amount after fees
 	fee, netAmount := s.CalculateRefundAmount(input.Amount, config.FeePercentage)
 
+	// Route the refund back to the sources the wallet was topped up with
+	split, err := s.SplitRefund(ctx, input.WalletID, netAmount)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate bank details
 	if input.BankDetails.IBAN == "" || input.BankDetails.AccountHolder == "" {
 		return nil, errors.New("INVALID_BANK_DETAILS", "IBAN and account holder are required")
@@ -107,6 +124,8 @@ func (s *Service) RequestRefund(ctx context.Context, userID uuid.UUID, input Cre
 		Reason:      input.Reason,
 		BankDetails: input.BankDetails,
 		Status:      RefundStatusPending,
+		CardAmount:  split.Card,
+		CashAmount:  split.Cash,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -120,6 +139,7 @@ func (s *Service) RequestRefund(ctx context.Context, userID uuid.UUID, input Cre
 		return nil, fmt.Errorf("failed to create refund request: %w", err)
 	}
 
+	s.recordActivity(ctx, refund, userID)
 	return refund, nil
 }
 
@@ -151,6 +171,7 @@ func (s *Service) ApproveRefund(ctx context.Context, refundID uuid.UUID, adminID
 	refund.ProcessedBy = &adminID
 	refund.UpdatedAt = now
 
+	s.recordActivity(ctx, refund, adminID)
 	return refund, nil
 }
 
@@ -186,6 +207,7 @@ func (s *Service) RejectRefund(ctx context.Context, refundID uuid.UUID, adminID
 	refund.ProcessedAt = &now
 	refund.UpdatedAt = now
 
+	s.recordActivity(ctx, refund, adminID)
 	return refund, nil
 }
 
@@ -203,6 +225,15 @@ func (s *Service) ProcessRefund(ctx context.Context, refundID uuid.UUID, adminID
 		return nil, errors.New("INVALID_STATUS", "Only approved refunds can be processed")
 	}
 
+	// The split is computed again, as the wallet may have been topped up since the request
+	split, err := s.SplitRefund(ctx, refund.WalletID, refund.NetAmount)
+	if err != nil {
+		return nil, err
+	}
+	if split.Cash > 0 && input.PaymentMethod == "stripe" {
+		return nil, errors.New("CASH_REFUND_REQUIRED", fmt.Sprintf("%s was topped up in cash and must be paid back in cash or by bank transfer", formatCents(split.Cash)))
+	}
+
 	// Update status to processing
 	now := time.Now()
 	updates := map[string]interface{}{
@@ -230,10 +261,9 @@ func (s *Service) ProcessRefund(ctx context.Context, refundID uuid.UUID, adminID
 		return nil, errors.ErrInsufficientBalance
 	}
 
-	// Process based on payment method
+	// The card part goes back to the card
 	var stripeRefundID string
-	switch input.PaymentMethod {
-	case "stripe":
+	if split.Card > 0 {
 		// In production, this would call Stripe API
 		// stripeRefund, err := stripe.Refunds.New(&stripe.RefundParams{...})
 		stripeRefundID = input.StripeRefundID
@@ -241,11 +271,11 @@ func (s *Service) ProcessRefund(ctx context.Context, refundID uuid.UUID, adminID
 			// Simulate Stripe refund ID for now
 			stripeRefundID = "re_" + uuid.New().String()[:24]
 		}
-	case "bank_transfer":
-		// In production, this would initiate a bank transfer
-		// For now, we just mark it as processed
 	}
 
+	// The cash part is handed back at the cash desk, or for bank_transfer initiated as a
+	// bank transfer; for now, we just mark it as processed
+
 	// Deduct from wallet balance
 	w.Balance -= refund.Amount
 	w.UpdatedAt = time.Now()
@@ -265,7 +295,7 @@ func (s *Service) ProcessRefund(ctx context.Context, refundID uuid.UUID, adminID
 		BalanceAfter:  w.Balance,
 		Reference:     refund.ID.String(),
 		Metadata: wallet.TransactionMeta{
-			Description:   "Refund processed",
+			Description:   fmt.Sprintf("Refund processed: %s to card, %s in cash", formatCents(split.Card), formatCents(split.Cash)),
 			PaymentMethod: input.PaymentMethod,
 		},
 		Status:    wallet.TransactionStatusCompleted,
@@ -279,6 +309,8 @@ func (s *Service) ProcessRefund(ctx context.Context, refundID uuid.UUID, adminID
 	completedAt := time.Now()
 	completedUpdates := map[string]interface{}{
 		"stripe_refund_id": stripeRefundID,
+		"card_amount":      split.Card,
+		"cash_amount":      split.Cash,
 		"processed_by":     adminID,
 		"processed_at":     completedAt,
 		"updated_at":       completedAt,
@@ -291,13 +323,23 @@ func (s *Service) ProcessRefund(ctx context.Context, refundID uuid.UUID, adminID
 	refund.Status = RefundStatusCompleted
 	refund.PaymentMethod = input.PaymentMethod
 	refund.StripeRefundID = stripeRefundID
+	refund.CardAmount = split.Card
+	refund.CashAmount = split.Cash
 	refund.ProcessedBy = &adminID
 	refund.ProcessedAt = &completedAt
 	refund.UpdatedAt = completedAt
 
+	s.recordActivity(ctx, refund, adminID)
 	return refund, nil
 }
 
+// recordActivity reports the new status of a refund to the activity feed
+func (s *Service) recordActivity(ctx context.Context, refund *RefundRequest, actorID uuid.UUID) {
+	if s.activity != nil {
+		s.activity.RecordRefund(ctx, refund.FestivalID, refund.ID, string(refund.Status), refund.Amount, &actorID)
+	}
+}
+
 // AutoProcessRefunds automatically processes all approved refunds for festivals with auto policy
 func (s *Service) AutoProcessRefunds(ctx context.Context) (int, error) {
 	processed := 0
@@ -345,6 +387,40 @@ func (s *Service) AutoProcessRefunds(ctx context.Context) (int, error) {
 	return processed, nil
 }
 
+// SplitRefund routes a refund of a wallet back to the sources it was topped up with
+func (s *Service) SplitRefund(ctx context.Context, walletID uuid.UUID, amount int64) (Split, error) {
+	funding, err := s.repo.GetFunding(ctx, walletID)
+	if err != nil {
+		return Split{}, err
+	}
+	return AllocateRefund(amount, *funding), nil
+}
+
+// AllocateRefund splits a refund between the card and cash in proportion to what the
+// wallet was topped up with from each. A source never gets back more than it funded:
+// card refunds are capped by the card payments, and the rest, such as compensations
+// credited by the organizer, is paid back in cash. Wallets without top-ups are refunded
+// in cash.
+func AllocateRefund(amount int64, funding Funding) Split {
+	if amount <= 0 {
+		return Split{}
+	}
+	card, cash := funding.Card, funding.Cash
+	if card < 0 {
+		card = 0
+	}
+	if cash < 0 {
+		cash = 0
+	}
+	if amount >= card+cash {
+		return Split{Card: card, Cash: amount - card}
+	}
+
+	// Rounded to the nearest cent, which keeps each part within its top-ups
+	cardPart := (amount*card + (card+cash)/2) / (card + cash)
+	return Split{Card: cardPart, Cash: amount - cardPart}
+}
+
 // CalculateRefundAmount calculates the net refund amount after deducting fees
 func (s *Service) CalculateRefundAmount(amount int64, feePercentage float64) (fee int64, netAmount int64) {
 	if feePercentage <= 0 {
@@ -392,6 +468,20 @@ func (s *Service) GetFestivalRefunds(ctx context.Context, festivalID uuid.UUID,
 	return s.repo.GetRefundsByFestival(ctx, festivalID, status, offset, perPage)
 }
 
+// GetSplitSummary totals what the refunds of a festival pay back to cards and in cash,
+// by status, so that cash desks know how much cash to hand back
+func (s *Service) GetSplitSummary(ctx context.Context, festivalID uuid.UUID) ([]SplitSummary, error) {
+	summaries, err := s.repo.GetSplitSummary(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		summaries[i].CardDisplay = formatCents(summaries[i].CardAmount)
+		summaries[i].CashDisplay = formatCents(summaries[i].CashAmount)
+	}
+	return summaries, nil
+}
+
 // GetPendingRefunds retrieves all pending refund requests
 func (s *Service) GetPendingRefunds(ctx context.Context, page, perPage int) ([]RefundRequest, int64, error) {
 	if page < 1 {
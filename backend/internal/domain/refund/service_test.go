@@ -0,0 +1,73 @@
+package refund
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocateRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		funding Funding
+		want    Split
+	}{
+		{
+			name:    "card only",
+			amount:  1500,
+			funding: Funding{Card: 5000},
+			want:    Split{Card: 1500},
+		},
+		{
+			name:    "cash only",
+			amount:  1500,
+			funding: Funding{Cash: 2000},
+			want:    Split{Cash: 1500},
+		},
+		{
+			name:    "proportional to the top-ups",
+			amount:  1000,
+			funding: Funding{Card: 3000, Cash: 1000},
+			want:    Split{Card: 750, Cash: 250},
+		},
+		{
+			name:    "rounded to the nearest cent",
+			amount:  1000,
+			funding: Funding{Card: 2000, Cash: 1000},
+			want:    Split{Card: 667, Cash: 333},
+		},
+		{
+			name:    "card capped by the card top-ups",
+			amount:  2500,
+			funding: Funding{Card: 1000, Cash: 1000},
+			want:    Split{Card: 1000, Cash: 1500},
+		},
+		{
+			name:    "no top-ups",
+			amount:  500,
+			funding: Funding{},
+			want:    Split{Cash: 500},
+		},
+		{
+			name:    "source already refunded",
+			amount:  500,
+			funding: Funding{Card: -200, Cash: 1000},
+			want:    Split{Cash: 500},
+		},
+		{
+			name:    "nothing to refund",
+			amount:  0,
+			funding: Funding{Card: 1000},
+			want:    Split{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllocateRefund(tt.amount, tt.funding)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.amount, got.Card+got.Cash)
+		})
+	}
+}
@@ -15,18 +15,29 @@ const (
 	ReportTypeTickets          ReportType = "TICKETS"
 	ReportTypeWallets          ReportType = "WALLETS"
 	ReportTypeStaffPerformance ReportType = "STAFF_PERFORMANCE"
+	ReportTypeStandFeedback    ReportType = "STAND_FEEDBACK"
+	ReportTypeSurveyResponses  ReportType = "SURVEY_RESPONSES"
+	ReportTypePayments         ReportType = "PAYMENTS"
 )
 
 // IsValid checks if the report type is valid
 func (rt ReportType) IsValid() bool {
 	switch rt {
 	case ReportTypeTransactions, ReportTypeSales, ReportTypeTickets,
-		ReportTypeWallets, ReportTypeStaffPerformance:
+		ReportTypeWallets, ReportTypeStaffPerformance, ReportTypeStandFeedback,
+		ReportTypeSurveyResponses, ReportTypePayments:
 		return true
 	}
 	return false
 }
 
+// IsFinancial reports whether reports of the type are financial records, which are
+// attested when generated
+func (rt ReportType) IsFinancial() bool {
+	return rt == ReportTypeTransactions || rt == ReportTypeSales || rt == ReportTypeWallets ||
+		rt == ReportTypePayments
+}
+
 // ReportFormat represents the output format for the report
 type ReportFormat string
 
@@ -94,6 +105,7 @@ type ReportFilters struct {
 	StandIDs     []uuid.UUID `json:"standIds,omitempty"`
 	StaffIDs     []uuid.UUID `json:"staffIds,omitempty"`
 	TicketTypes  []uuid.UUID `json:"ticketTypes,omitempty"`
+	SurveyIDs    []uuid.UUID `json:"surveyIds,omitempty"`
 	Status       []string    `json:"status,omitempty"`
 	MinAmount    *int64      `json:"minAmount,omitempty"`
 	MaxAmount    *int64      `json:"maxAmount,omitempty"`
@@ -105,6 +117,7 @@ type ReportRequest struct {
 	Format     ReportFormat  `json:"format" binding:"required"`
 	DateRange  *DateRange    `json:"dateRange,omitempty"`
 	Filters    *ReportFilters `json:"filters,omitempty"`
+	Locale     string        `json:"locale,omitempty"` // en, fr, de or nl; defaults to the requester's language
 }
 
 // Report represents a report record stored in the database
@@ -114,10 +127,12 @@ type Report struct {
 	RequestedBy uuid.UUID     `json:"requestedBy" gorm:"type:uuid;not null"`
 	Type        ReportType    `json:"type" gorm:"not null"`
 	Format      ReportFormat  `json:"format" gorm:"not null"`
+	Locale      string        `json:"locale" gorm:"default:'en'"`
 	Status      ReportStatus  `json:"status" gorm:"default:'PENDING'"`
 	FileName    string        `json:"fileName,omitempty"`
 	FilePath    string        `json:"filePath,omitempty"`
 	FileSize    int64         `json:"fileSize,omitempty"`
+	ContentHash string        `json:"contentHash,omitempty"` // Hex SHA-256 of the file of attested financial reports
 	RowCount    int           `json:"rowCount,omitempty"`
 	DateRange   *DateRange    `json:"dateRange,omitempty" gorm:"type:jsonb"`
 	Filters     *ReportFilters `json:"filters,omitempty" gorm:"type:jsonb"`
@@ -148,6 +163,7 @@ type ReportResponse struct {
 	Status      ReportStatus  `json:"status"`
 	FileName    string        `json:"fileName,omitempty"`
 	FileSize    int64         `json:"fileSize,omitempty"`
+	ContentHash string        `json:"contentHash,omitempty"` // Hex SHA-256 of the file of attested financial reports
 	RowCount    int           `json:"rowCount,omitempty"`
 	DownloadURL string        `json:"downloadUrl,omitempty"`
 	Error       string        `json:"error,omitempty"`
@@ -207,6 +223,7 @@ type TransactionExport struct {
 	StaffID       *uuid.UUID `json:"staffId"`
 	StaffName     string     `json:"staffName"`
 	Status        string     `json:"status"`
+	Source        string     `json:"source,omitempty"` // Previous cashless provider of imported transactions
 	CreatedAt     time.Time  `json:"createdAt"`
 }
 
@@ -242,17 +259,18 @@ type TicketExport struct {
 
 // WalletExport represents a wallet row for export
 type WalletExport struct {
-	ID              uuid.UUID `json:"id"`
-	UserID          uuid.UUID `json:"userId"`
-	UserEmail       string    `json:"userEmail"`
-	UserName        string    `json:"userName"`
-	Balance         int64     `json:"balance"`
-	BalanceDisplay  string    `json:"balanceDisplay"`
-	Status          string    `json:"status"`
-	TotalTopUps     int64     `json:"totalTopUps"`
-	TotalPurchases  int64     `json:"totalPurchases"`
-	TransactionCount int      `json:"transactionCount"`
-	CreatedAt       time.Time `json:"createdAt"`
+	ID               uuid.UUID  `json:"id"`
+	UserID           *uuid.UUID `json:"userId"` // Nil for anonymous wallets
+	UserEmail        string     `json:"userEmail"`
+	UserName         string     `json:"userName"`
+	Balance          int64      `json:"balance"`
+	BalanceDisplay   string     `json:"balanceDisplay"`
+	Status           string     `json:"status"`
+	TotalTopUps      int64      `json:"totalTopUps"`
+	TotalPurchases   int64      `json:"totalPurchases"`
+	TransactionCount int        `json:"transactionCount"`
+	Source           string     `json:"source,omitempty"` // Previous cashless provider of imported wallets
+	CreatedAt        time.Time  `json:"createdAt"`
 }
 
 // StaffPerformanceExport represents a staff performance row for export
@@ -272,6 +290,48 @@ type StaffPerformanceExport struct {
 	Refunds          int       `json:"refunds"`
 }
 
+// StandFeedbackExport represents an attendee feedback row for export
+type StandFeedbackExport struct {
+	FeedbackID uuid.UUID `json:"feedbackId"`
+	StandID    uuid.UUID `json:"standId"`
+	StandName  string    `json:"standName"`
+	OrderID    uuid.UUID `json:"orderId"`
+	Rating     int       `json:"rating"`
+	Comment    string    `json:"comment"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// SurveyAnswerExport represents a survey answer row for export
+type SurveyAnswerExport struct {
+	SubmissionID     uuid.UUID `json:"submissionId"`
+	SurveyID         uuid.UUID `json:"surveyId"`
+	SurveyTitle      string    `json:"surveyTitle"`
+	QuestionPosition int       `json:"questionPosition"`
+	QuestionPrompt   string    `json:"questionPrompt"`
+	QuestionType     string    `json:"questionType"`
+	Answer           string    `json:"answer"`
+	SubmittedAt      time.Time `json:"submittedAt"`
+}
+
+// PaymentExport represents a card payment row for export, its amount split between
+// ticketing and cashless
+type PaymentExport struct {
+	ID                   uuid.UUID  `json:"id"`
+	StripeIntentID       string     `json:"stripeIntentId"`
+	Kind                 string     `json:"kind"` // TOP_UP, TICKET, BUNDLE or PRESALE
+	CustomerEmail        string     `json:"customerEmail"`
+	Amount               int64      `json:"amount"`
+	TicketAmount         int64      `json:"ticketAmount"`
+	CreditAmount         int64      `json:"creditAmount"`
+	PlatformFee          int64      `json:"platformFee"`
+	RefundedTicketAmount int64      `json:"refundedTicketAmount"`
+	RefundedCreditAmount int64      `json:"refundedCreditAmount"`
+	Status               string     `json:"status"`
+	CompletedAt          *time.Time `json:"completedAt"`
+	CreatedAt            time.Time  `json:"createdAt"`
+}
+
 // ReportTaskPayload represents the payload for async report generation
 type ReportTaskPayload struct {
 	ReportID   uuid.UUID `json:"reportId"`
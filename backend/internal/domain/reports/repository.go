@@ -24,6 +24,9 @@ type Repository interface {
 	GetTicketsForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]TicketExport, error)
 	GetWalletsForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]WalletExport, error)
 	GetStaffPerformanceForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]StaffPerformanceExport, error)
+	GetStandFeedbackForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]StandFeedbackExport, error)
+	GetSurveyAnswersForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]SurveyAnswerExport, error)
+	GetPaymentsForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]PaymentExport, error)
 }
 
 type repository struct {
@@ -101,12 +104,14 @@ func (r *repository) GetTransactionsForExport(ctx context.Context, festivalID uu
 			t.staff_id,
 			COALESCE(staff.name, '') as staff_name,
 			t.status,
+			COALESCE(li.source, '') as source,
 			t.created_at
 		FROM public.transactions t
 		INNER JOIN public.wallets w ON t.wallet_id = w.id
 		LEFT JOIN public.users u ON w.user_id = u.id
 		LEFT JOIN public.stands s ON t.stand_id = s.id
 		LEFT JOIN public.users staff ON t.staff_id = staff.id
+		LEFT JOIN public.legacy_imports li ON li.id = t.legacy_import_id
 		WHERE w.festival_id = ?`
 
 	args := []interface{}{festivalID}
@@ -156,6 +161,7 @@ func (r *repository) GetTransactionsForExport(ctx context.Context, festivalID uu
 		StaffID       *uuid.UUID
 		StaffName     string
 		Status        string
+		Source        string
 		CreatedAt     time.Time
 	}
 
@@ -181,6 +187,7 @@ func (r *repository) GetTransactionsForExport(ctx context.Context, festivalID uu
 			StaffID:       row.StaffID,
 			StaffName:     row.StaffName,
 			Status:        row.Status,
+			Source:        row.Source,
 			CreatedAt:     row.CreatedAt,
 		}
 	}
@@ -351,9 +358,11 @@ func (r *repository) GetWalletsForExport(ctx context.Context, festivalID uuid.UU
 			COALESCE(tx_stats.total_top_ups, 0) as total_top_ups,
 			COALESCE(tx_stats.total_purchases, 0) as total_purchases,
 			COALESCE(tx_stats.transaction_count, 0) as transaction_count,
+			COALESCE(li.source, '') as source,
 			w.created_at
 		FROM public.wallets w
 		LEFT JOIN public.users u ON w.user_id = u.id
+		LEFT JOIN public.legacy_imports li ON li.id = w.legacy_import_id
 		LEFT JOIN (
 			SELECT
 				wallet_id,
@@ -382,7 +391,7 @@ func (r *repository) GetWalletsForExport(ctx context.Context, festivalID uuid.UU
 
 	var results []struct {
 		ID               uuid.UUID
-		UserID           uuid.UUID
+		UserID           *uuid.UUID
 		UserEmail        string
 		UserName         string
 		Balance          int64
@@ -390,6 +399,7 @@ func (r *repository) GetWalletsForExport(ctx context.Context, festivalID uuid.UU
 		TotalTopUps      int64
 		TotalPurchases   int64
 		TransactionCount int
+		Source           string
 		CreatedAt        time.Time
 	}
 
@@ -410,6 +420,7 @@ func (r *repository) GetWalletsForExport(ctx context.Context, festivalID uuid.UU
 			TotalTopUps:      row.TotalTopUps,
 			TotalPurchases:   row.TotalPurchases,
 			TransactionCount: row.TransactionCount,
+			Source:           row.Source,
 			CreatedAt:        row.CreatedAt,
 		}
 	}
@@ -501,6 +512,150 @@ func (r *repository) GetStaffPerformanceForExport(ctx context.Context, festivalI
 	return exports, nil
 }
 
+// GetStandFeedbackForExport retrieves attendee feedback for export; hidden feedback is excluded
+func (r *repository) GetStandFeedbackForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]StandFeedbackExport, error) {
+	query := `
+		SELECT
+			f.id as feedback_id,
+			f.stand_id,
+			COALESCE(s.name, 'Unknown') as stand_name,
+			f.order_id,
+			f.rating,
+			f.comment,
+			f.status,
+			f.created_at
+		FROM public.stand_feedback f
+		LEFT JOIN public.stands s ON f.stand_id = s.id
+		WHERE f.festival_id = ?
+			AND f.status <> 'HIDDEN'`
+
+	args := []interface{}{festivalID}
+
+	if dateRange != nil {
+		query += " AND f.created_at >= ? AND f.created_at <= ?"
+		args = append(args, dateRange.StartDate, dateRange.EndDate)
+	}
+
+	if filters != nil && len(filters.StandIDs) > 0 {
+		query += " AND f.stand_id IN (?)"
+		args = append(args, filters.StandIDs)
+	}
+
+	query += " ORDER BY s.name ASC, f.created_at DESC"
+
+	var exports []StandFeedbackExport
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&exports).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stand feedback for export: %w", err)
+	}
+
+	return exports, nil
+}
+
+// GetSurveyAnswersForExport retrieves survey answers for export, one row per answered question
+func (r *repository) GetSurveyAnswersForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]SurveyAnswerExport, error) {
+	query := `
+		SELECT
+			a.submission_id,
+			s.id as survey_id,
+			s.title as survey_title,
+			COALESCE(q.position, 0) as question_position,
+			COALESCE(q.question->>'prompt', '') as question_prompt,
+			COALESCE(q.question->>'type', '') as question_type,
+			CASE
+				WHEN a.rating IS NOT NULL THEN a.rating::text
+				WHEN jsonb_typeof(a.choices) = 'array' AND jsonb_array_length(a.choices) > 0 THEN
+					array_to_string(ARRAY(SELECT jsonb_array_elements_text(a.choices)), '; ')
+				ELSE a.text
+			END as answer,
+			sub.submitted_at
+		FROM public.survey_answers a
+		INNER JOIN public.survey_submissions sub ON a.submission_id = sub.id
+		INNER JOIN public.surveys s ON a.survey_id = s.id
+		LEFT JOIN LATERAL jsonb_array_elements(s.questions) WITH ORDINALITY AS q(question, position)
+			ON (q.question->>'id')::uuid = a.question_id
+		WHERE s.festival_id = ?`
+
+	args := []interface{}{festivalID}
+
+	if dateRange != nil {
+		query += " AND sub.submitted_at >= ? AND sub.submitted_at <= ?"
+		args = append(args, dateRange.StartDate, dateRange.EndDate)
+	}
+
+	if filters != nil && len(filters.SurveyIDs) > 0 {
+		query += " AND s.id IN (?)"
+		args = append(args, filters.SurveyIDs)
+	}
+
+	query += " ORDER BY s.title ASC, sub.submitted_at ASC, a.submission_id, question_position"
+
+	var exports []SurveyAnswerExport
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&exports).Error; err != nil {
+		return nil, fmt.Errorf("failed to get survey answers for export: %w", err)
+	}
+
+	return exports, nil
+}
+
+// GetPaymentsForExport retrieves card payments for export. Refunds of a bundle are split as
+// its purchase unwound them; refunds of other payments all go to their single part.
+func (r *repository) GetPaymentsForExport(ctx context.Context, festivalID uuid.UUID, dateRange *DateRange, filters *ReportFilters) ([]PaymentExport, error) {
+	query := `
+		SELECT
+			pi.id,
+			pi.stripe_intent_id,
+			pi.kind,
+			COALESCE(pi.customer_email, '') as customer_email,
+			pi.amount,
+			pi.ticket_amount,
+			pi.credit_amount,
+			pi.platform_fee,
+			CASE pi.kind
+				WHEN 'BUNDLE' THEN COALESCE(bp.refunded_ticket_amount, 0)
+				WHEN 'TICKET' THEN COALESCE(rf.amount, 0)
+				ELSE 0
+			END as refunded_ticket_amount,
+			CASE pi.kind
+				WHEN 'BUNDLE' THEN COALESCE(bp.refunded_credit_amount, 0)
+				WHEN 'TOP_UP', 'PRESALE' THEN COALESCE(rf.amount, 0)
+				ELSE 0
+			END as refunded_credit_amount,
+			pi.status,
+			pi.completed_at,
+			pi.created_at
+		FROM public.payment_intents pi
+		LEFT JOIN public.bundle_purchases bp
+			ON bp.stripe_intent_id = pi.stripe_intent_id AND bp.refunded_at IS NOT NULL
+		LEFT JOIN (
+			SELECT payment_intent_id, SUM(amount) as amount
+			FROM public.refunds
+			WHERE status = 'succeeded'
+			GROUP BY payment_intent_id
+		) rf ON rf.payment_intent_id = pi.id
+		WHERE pi.festival_id = ?`
+
+	args := []interface{}{festivalID}
+
+	if dateRange != nil {
+		query += " AND pi.created_at >= ? AND pi.created_at <= ?"
+		args = append(args, dateRange.StartDate, dateRange.EndDate)
+	}
+
+	if filters != nil && len(filters.Status) > 0 {
+		query += " AND pi.status IN (?)"
+		args = append(args, filters.Status)
+	}
+
+	query += " ORDER BY pi.created_at DESC"
+
+	var exports []PaymentExport
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&exports).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payments for export: %w", err)
+	}
+
+	return exports, nil
+}
+
 // formatCurrency formats cents to a currency display string
 func formatCurrency(cents int64) string {
 	euros := float64(cents) / 100
@@ -9,11 +9,17 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/jung-kurt/gofpdf"
+	"github.com/mimi6060/festivals/backend/internal/domain/attestation"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/domain/residency"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -32,10 +38,26 @@ type StorageService interface {
 	Delete(ctx context.Context, key string) error
 }
 
+// StorageRouter picks the storage of a festival's data under its data residency
+// policy; satisfied by residency.Service
+type StorageRouter interface {
+	Route(ctx context.Context, festivalID uuid.UUID, kind residency.Kind) (*residency.Provider, error)
+}
+
+// Attester seals financial reports in the attestation chain of the festival; satisfied
+// by attestation.Service
+type Attester interface {
+	Attest(ctx context.Context, req attestation.AttestRequest) (*attestation.Attestation, error)
+}
+
 // Service provides report generation functionality
 type Service struct {
 	repo        Repository
 	storage     StorageService
+	euStorage   StorageService // Storage of festivals pinned to the EU when the default one is not in the EU
+	router      StorageRouter
+	jobs        realtime.JobBroadcaster
+	attester    Attester
 	asynqClient *asynq.Client
 	storagePath string // Local storage path for reports
 }
@@ -50,6 +72,48 @@ func NewService(repo Repository, storage StorageService, asynqClient *asynq.Clie
 	}
 }
 
+// SetResidency sets the residency policies routing the reports of festivals pinned to
+// the EU to the EU storage
+func (s *Service) SetResidency(router StorageRouter, euStorage StorageService) {
+	s.router = router
+	s.euStorage = euStorage
+}
+
+// SetJobBroadcaster reports the progress of report generation to the dashboards
+func (s *Service) SetJobBroadcaster(jobs realtime.JobBroadcaster) {
+	s.jobs = jobs
+}
+
+// SetAttester seals the file of every financial report generated, so that auditors
+// can prove an export was not modified after generation
+func (s *Service) SetAttester(attester Attester) {
+	s.attester = attester
+}
+
+// trackJob tracks the generation of a report for the jobs panel of the dashboard
+func (s *Service) trackJob(report *Report) *realtime.JobTracker {
+	title := fmt.Sprintf("%s (%s)", s.getReportTitle(report.Type, report.Locale), report.Format)
+	return realtime.TrackJob(s.jobs, report.FestivalID.String(), report.ID.String(), realtime.JobKindReport, title)
+}
+
+// storageFor returns the storage of a festival's reports, nil for local storage
+func (s *Service) storageFor(ctx context.Context, festivalID uuid.UUID) (StorageService, error) {
+	if s.router == nil {
+		return s.storage, nil
+	}
+	provider, err := s.router.Route(ctx, festivalID, residency.KindStorage)
+	if err != nil {
+		return nil, err
+	}
+	if provider != nil && provider.ID == residency.ProviderMinioEU {
+		if s.euStorage == nil {
+			return nil, fmt.Errorf("%w: EU storage is not available", residency.ErrProviderOutsideEU)
+		}
+		return s.euStorage, nil
+	}
+	return s.storage, nil
+}
+
 // RequestReport creates a new report request and enqueues it for async processing
 func (s *Service) RequestReport(ctx context.Context, festivalID, userID uuid.UUID, req ReportRequest) (*Report, error) {
 	// Validate request
@@ -60,6 +124,12 @@ func (s *Service) RequestReport(ctx context.Context, festivalID, userID uuid.UUI
 		return nil, fmt.Errorf("invalid report format: %s", req.Format)
 	}
 
+	// Documents are generated in the requested locale, else in the requester's
+	locale := i18n.Normalize(req.Locale)
+	if locale == "" {
+		locale = i18n.FromContext(ctx)
+	}
+
 	// Create report record
 	report := &Report{
 		ID:          uuid.New(),
@@ -67,6 +137,7 @@ func (s *Service) RequestReport(ctx context.Context, festivalID, userID uuid.UUI
 		RequestedBy: userID,
 		Type:        req.Type,
 		Format:      req.Format,
+		Locale:      locale,
 		Status:      ReportStatusPending,
 		DateRange:   req.DateRange,
 		Filters:     req.Filters,
@@ -86,6 +157,7 @@ func (s *Service) RequestReport(ctx context.Context, festivalID, userID uuid.UUI
 		_ = s.repo.UpdateReport(ctx, report)
 		return nil, fmt.Errorf("failed to enqueue report task: %w", err)
 	}
+	s.trackJob(report).Queued(ctx)
 
 	return report, nil
 }
@@ -124,6 +196,8 @@ func (s *Service) GenerateReport(ctx context.Context, reportID uuid.UUID) error
 	if err := s.repo.UpdateReport(ctx, report); err != nil {
 		return fmt.Errorf("failed to update report status: %w", err)
 	}
+	job := s.trackJob(report)
+	job.Started(ctx)
 
 	// Generate report based on type
 	var data interface{}
@@ -169,19 +243,44 @@ func (s *Service) GenerateReport(ctx context.Context, reportID uuid.UUID) error
 		data = staffData
 		rowCount = len(staffData)
 
+	case ReportTypeStandFeedback:
+		feedbackData, err := s.repo.GetStandFeedbackForExport(ctx, report.FestivalID, report.DateRange, report.Filters)
+		if err != nil {
+			return s.failReport(ctx, report, err)
+		}
+		data = feedbackData
+		rowCount = len(feedbackData)
+
+	case ReportTypeSurveyResponses:
+		surveyData, err := s.repo.GetSurveyAnswersForExport(ctx, report.FestivalID, report.DateRange, report.Filters)
+		if err != nil {
+			return s.failReport(ctx, report, err)
+		}
+		data = surveyData
+		rowCount = len(surveyData)
+
+	case ReportTypePayments:
+		paymentData, err := s.repo.GetPaymentsForExport(ctx, report.FestivalID, report.DateRange, report.Filters)
+		if err != nil {
+			return s.failReport(ctx, report, err)
+		}
+		data = paymentData
+		rowCount = len(paymentData)
+
 	default:
 		return s.failReport(ctx, report, fmt.Errorf("unsupported report type: %s", report.Type))
 	}
+	job.Progress(ctx, 1, 3)
 
 	// Generate file based on format
 	var fileData []byte
 	switch report.Format {
 	case ReportFormatCSV:
-		fileData, err = s.generateCSV(report.Type, data)
+		fileData, err = s.generateCSV(report.Type, report.Locale, data)
 	case ReportFormatXLSX:
-		fileData, err = s.generateXLSX(report.Type, data)
+		fileData, err = s.generateXLSX(report.Type, report.Locale, data)
 	case ReportFormatPDF:
-		fileData, err = s.generatePDF(report.Type, data)
+		fileData, err = s.generatePDF(report.Type, report.Locale, data)
 	default:
 		err = fmt.Errorf("unsupported format: %s", report.Format)
 	}
@@ -189,6 +288,7 @@ func (s *Service) GenerateReport(ctx context.Context, reportID uuid.UUID) error
 	if err != nil {
 		return s.failReport(ctx, report, err)
 	}
+	job.Progress(ctx, 2, 3)
 
 	// Generate filename
 	fileName := s.generateFileName(report)
@@ -199,6 +299,21 @@ func (s *Service) GenerateReport(ctx context.Context, reportID uuid.UUID) error
 		return s.failReport(ctx, report, err)
 	}
 
+	// Seal financial exports; an export that cannot be attested is not handed out
+	if s.attester != nil && report.Type.IsFinancial() {
+		_, err := s.attester.Attest(ctx, attestation.AttestRequest{
+			FestivalID:     report.FestivalID,
+			DocumentType:   attestation.DocumentReport,
+			DocumentID:     report.ID,
+			DocumentNumber: fileName,
+			Content:        fileData,
+		})
+		if err != nil {
+			return s.failReport(ctx, report, fmt.Errorf("failed to attest report: %w", err))
+		}
+		report.ContentHash = attestation.ContentHash(fileData)
+	}
+
 	// Update report as completed
 	now := time.Now()
 	expiresAt := now.Add(DefaultReportExpiry)
@@ -215,6 +330,12 @@ func (s *Service) GenerateReport(ctx context.Context, reportID uuid.UUID) error
 		return fmt.Errorf("failed to update completed report: %w", err)
 	}
 
+	if job != nil {
+		// The report is completed even when no download link can be signed
+		url, _ := s.GetReportURL(ctx, report)
+		job.Completed(ctx, url)
+	}
+
 	return nil
 }
 
@@ -224,6 +345,7 @@ func (s *Service) failReport(ctx context.Context, report *Report, err error) err
 	report.Error = err.Error()
 	report.UpdatedAt = time.Now()
 	_ = s.repo.UpdateReport(ctx, report)
+	s.trackJob(report).Failed(ctx, err)
 	return err
 }
 
@@ -242,9 +364,13 @@ func (s *Service) generateFileName(report *Report) string {
 func (s *Service) uploadToStorage(ctx context.Context, festivalID uuid.UUID, fileName string, data []byte, format ReportFormat) (string, error) {
 	key := fmt.Sprintf("reports/%s/%s", festivalID, fileName)
 
-	if s.storage != nil {
+	storage, err := s.storageFor(ctx, festivalID)
+	if err != nil {
+		return "", err
+	}
+	if storage != nil {
 		// Use cloud storage
-		if err := s.storage.Upload(ctx, key, bytes.NewReader(data), format.GetContentType()); err != nil {
+		if err := storage.Upload(ctx, key, bytes.NewReader(data), format.GetContentType()); err != nil {
 			return "", fmt.Errorf("failed to upload to storage: %w", err)
 		}
 		return key, nil
@@ -270,9 +396,13 @@ func (s *Service) GetReportURL(ctx context.Context, report *Report) (string, err
 		return "", nil
 	}
 
-	if s.storage != nil {
+	storage, err := s.storageFor(ctx, report.FestivalID)
+	if err != nil {
+		return "", err
+	}
+	if storage != nil {
 		// Get signed URL from cloud storage
-		url, err := s.storage.GetSignedURL(ctx, report.FilePath, time.Hour)
+		url, err := storage.GetSignedURL(ctx, report.FilePath, time.Hour)
 		if err != nil {
 			return "", fmt.Errorf("failed to get signed URL: %w", err)
 		}
@@ -310,29 +440,41 @@ func (s *Service) GetLocalFilePath(report *Report) string {
 }
 
 // generateCSV generates a CSV file from the data
-func (s *Service) generateCSV(reportType ReportType, data interface{}) ([]byte, error) {
+func (s *Service) generateCSV(reportType ReportType, locale string, data interface{}) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
 	switch reportType {
 	case ReportTypeTransactions:
-		if err := s.writeTransactionsCSV(writer, data.([]TransactionExport)); err != nil {
+		if err := s.writeTransactionsCSV(writer, locale, data.([]TransactionExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeSales:
-		if err := s.writeSalesCSV(writer, data.([]SalesExport)); err != nil {
+		if err := s.writeSalesCSV(writer, locale, data.([]SalesExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeTickets:
-		if err := s.writeTicketsCSV(writer, data.([]TicketExport)); err != nil {
+		if err := s.writeTicketsCSV(writer, locale, data.([]TicketExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeWallets:
-		if err := s.writeWalletsCSV(writer, data.([]WalletExport)); err != nil {
+		if err := s.writeWalletsCSV(writer, locale, data.([]WalletExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeStaffPerformance:
-		if err := s.writeStaffPerformanceCSV(writer, data.([]StaffPerformanceExport)); err != nil {
+		if err := s.writeStaffPerformanceCSV(writer, locale, data.([]StaffPerformanceExport)); err != nil {
+			return nil, err
+		}
+	case ReportTypeStandFeedback:
+		if err := s.writeStandFeedbackCSV(writer, locale, data.([]StandFeedbackExport)); err != nil {
+			return nil, err
+		}
+	case ReportTypeSurveyResponses:
+		if err := s.writeSurveyAnswersCSV(writer, locale, data.([]SurveyAnswerExport)); err != nil {
+			return nil, err
+		}
+	case ReportTypePayments:
+		if err := s.writePaymentsCSV(writer, locale, data.([]PaymentExport)); err != nil {
 			return nil, err
 		}
 	}
@@ -345,9 +487,10 @@ func (s *Service) generateCSV(reportType ReportType, data interface{}) ([]byte,
 	return buf.Bytes(), nil
 }
 
-func (s *Service) writeTransactionsCSV(writer *csv.Writer, data []TransactionExport) error {
+func (s *Service) writeTransactionsCSV(writer *csv.Writer, locale string, data []TransactionExport) error {
 	headers := []string{"ID", "Wallet ID", "User Email", "User Name", "Type", "Amount", "Amount Display",
-		"Balance Before", "Balance After", "Reference", "Stand ID", "Stand Name", "Staff ID", "Staff Name", "Status", "Created At"}
+		"Balance Before", "Balance After", "Reference", "Stand ID", "Stand Name", "Staff ID", "Staff Name", "Status", "Source", "Created At"}
+	headers = localizeHeaders(locale, headers)
 	if err := writer.Write(headers); err != nil {
 		return err
 	}
@@ -369,6 +512,7 @@ func (s *Service) writeTransactionsCSV(writer *csv.Writer, data []TransactionExp
 			uuidPtrToString(row.StaffID),
 			row.StaffName,
 			row.Status,
+			row.Source,
 			row.CreatedAt.Format(time.RFC3339),
 		}
 		if err := writer.Write(record); err != nil {
@@ -378,8 +522,9 @@ func (s *Service) writeTransactionsCSV(writer *csv.Writer, data []TransactionExp
 	return nil
 }
 
-func (s *Service) writeSalesCSV(writer *csv.Writer, data []SalesExport) error {
+func (s *Service) writeSalesCSV(writer *csv.Writer, locale string, data []SalesExport) error {
 	headers := []string{"Stand ID", "Stand Name", "Product ID", "Product Name", "Quantity", "Unit Price", "Total Revenue", "Revenue Display", "Date"}
+	headers = localizeHeaders(locale, headers)
 	if err := writer.Write(headers); err != nil {
 		return err
 	}
@@ -403,9 +548,10 @@ func (s *Service) writeSalesCSV(writer *csv.Writer, data []SalesExport) error {
 	return nil
 }
 
-func (s *Service) writeTicketsCSV(writer *csv.Writer, data []TicketExport) error {
+func (s *Service) writeTicketsCSV(writer *csv.Writer, locale string, data []TicketExport) error {
 	headers := []string{"ID", "Code", "Ticket Type ID", "Ticket Type", "Price", "Price Display",
 		"Holder Name", "Holder Email", "User ID", "Status", "Checked In At", "Checked In By", "Created At"}
+	headers = localizeHeaders(locale, headers)
 	if err := writer.Write(headers); err != nil {
 		return err
 	}
@@ -437,9 +583,10 @@ func (s *Service) writeTicketsCSV(writer *csv.Writer, data []TicketExport) error
 	return nil
 }
 
-func (s *Service) writeWalletsCSV(writer *csv.Writer, data []WalletExport) error {
+func (s *Service) writeWalletsCSV(writer *csv.Writer, locale string, data []WalletExport) error {
 	headers := []string{"ID", "User ID", "User Email", "User Name", "Balance", "Balance Display",
-		"Status", "Total Top Ups", "Total Purchases", "Transaction Count", "Created At"}
+		"Status", "Total Top Ups", "Total Purchases", "Transaction Count", "Source", "Created At"}
+	headers = localizeHeaders(locale, headers)
 	if err := writer.Write(headers); err != nil {
 		return err
 	}
@@ -447,7 +594,7 @@ func (s *Service) writeWalletsCSV(writer *csv.Writer, data []WalletExport) error
 	for _, row := range data {
 		record := []string{
 			row.ID.String(),
-			row.UserID.String(),
+			uuidPtrToString(row.UserID),
 			row.UserEmail,
 			row.UserName,
 			fmt.Sprintf("%d", row.Balance),
@@ -456,6 +603,7 @@ func (s *Service) writeWalletsCSV(writer *csv.Writer, data []WalletExport) error
 			fmt.Sprintf("%d", row.TotalTopUps),
 			fmt.Sprintf("%d", row.TotalPurchases),
 			fmt.Sprintf("%d", row.TransactionCount),
+			row.Source,
 			row.CreatedAt.Format(time.RFC3339),
 		}
 		if err := writer.Write(record); err != nil {
@@ -465,10 +613,11 @@ func (s *Service) writeWalletsCSV(writer *csv.Writer, data []WalletExport) error
 	return nil
 }
 
-func (s *Service) writeStaffPerformanceCSV(writer *csv.Writer, data []StaffPerformanceExport) error {
+func (s *Service) writeStaffPerformanceCSV(writer *csv.Writer, locale string, data []StaffPerformanceExport) error {
 	headers := []string{"Staff ID", "Staff Name", "Staff Email", "Stand ID", "Stand Name",
 		"Transactions", "Total Amount", "Amount Display", "Average Amount", "Avg Amount Display",
 		"Top Ups", "Purchases", "Refunds"}
+	headers = localizeHeaders(locale, headers)
 	if err := writer.Write(headers); err != nil {
 		return err
 	}
@@ -496,33 +645,130 @@ func (s *Service) writeStaffPerformanceCSV(writer *csv.Writer, data []StaffPerfo
 	return nil
 }
 
+func (s *Service) writeStandFeedbackCSV(writer *csv.Writer, locale string, data []StandFeedbackExport) error {
+	headers := []string{"Feedback ID", "Stand ID", "Stand Name", "Order ID", "Rating", "Comment", "Status", "Created At"}
+	headers = localizeHeaders(locale, headers)
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, row := range data {
+		record := []string{
+			row.FeedbackID.String(),
+			row.StandID.String(),
+			row.StandName,
+			row.OrderID.String(),
+			fmt.Sprintf("%d", row.Rating),
+			row.Comment,
+			row.Status,
+			row.CreatedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) writeSurveyAnswersCSV(writer *csv.Writer, locale string, data []SurveyAnswerExport) error {
+	headers := []string{"Submission ID", "Survey ID", "Survey Title", "Question #", "Question", "Question Type", "Answer", "Submitted At"}
+	headers = localizeHeaders(locale, headers)
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, row := range data {
+		record := []string{
+			row.SubmissionID.String(),
+			row.SurveyID.String(),
+			row.SurveyTitle,
+			fmt.Sprintf("%d", row.QuestionPosition),
+			row.QuestionPrompt,
+			row.QuestionType,
+			row.Answer,
+			row.SubmittedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) writePaymentsCSV(writer *csv.Writer, locale string, data []PaymentExport) error {
+	headers := []string{"ID", "Stripe Intent ID", "Kind", "Customer Email", "Amount", "Ticketing", "Cashless",
+		"Platform Fee", "Refunded Ticketing", "Refunded Cashless", "Status", "Completed At", "Created At"}
+	headers = localizeHeaders(locale, headers)
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, row := range data {
+		completedAt := ""
+		if row.CompletedAt != nil {
+			completedAt = row.CompletedAt.Format(time.RFC3339)
+		}
+		record := []string{
+			row.ID.String(),
+			row.StripeIntentID,
+			row.Kind,
+			row.CustomerEmail,
+			fmt.Sprintf("%d", row.Amount),
+			fmt.Sprintf("%d", row.TicketAmount),
+			fmt.Sprintf("%d", row.CreditAmount),
+			fmt.Sprintf("%d", row.PlatformFee),
+			fmt.Sprintf("%d", row.RefundedTicketAmount),
+			fmt.Sprintf("%d", row.RefundedCreditAmount),
+			row.Status,
+			completedAt,
+			row.CreatedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // generateXLSX generates an XLSX file from the data using excelize
-func (s *Service) generateXLSX(reportType ReportType, data interface{}) ([]byte, error) {
+func (s *Service) generateXLSX(reportType ReportType, locale string, data interface{}) ([]byte, error) {
 	f := excelize.NewFile()
 	defer f.Close()
 
-	sheetName := "Report"
+	sheetName := i18n.T(locale, "report.sheet")
 	f.SetSheetName("Sheet1", sheetName)
 
 	switch reportType {
 	case ReportTypeTransactions:
-		if err := s.writeTransactionsXLSX(f, sheetName, data.([]TransactionExport)); err != nil {
+		if err := s.writeTransactionsXLSX(f, sheetName, locale, data.([]TransactionExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeSales:
-		if err := s.writeSalesXLSX(f, sheetName, data.([]SalesExport)); err != nil {
+		if err := s.writeSalesXLSX(f, sheetName, locale, data.([]SalesExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeTickets:
-		if err := s.writeTicketsXLSX(f, sheetName, data.([]TicketExport)); err != nil {
+		if err := s.writeTicketsXLSX(f, sheetName, locale, data.([]TicketExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeWallets:
-		if err := s.writeWalletsXLSX(f, sheetName, data.([]WalletExport)); err != nil {
+		if err := s.writeWalletsXLSX(f, sheetName, locale, data.([]WalletExport)); err != nil {
 			return nil, err
 		}
 	case ReportTypeStaffPerformance:
-		if err := s.writeStaffPerformanceXLSX(f, sheetName, data.([]StaffPerformanceExport)); err != nil {
+		if err := s.writeStaffPerformanceXLSX(f, sheetName, locale, data.([]StaffPerformanceExport)); err != nil {
+			return nil, err
+		}
+	case ReportTypeStandFeedback:
+		if err := s.writeStandFeedbackXLSX(f, sheetName, locale, data.([]StandFeedbackExport)); err != nil {
+			return nil, err
+		}
+	case ReportTypeSurveyResponses:
+		if err := s.writeSurveyAnswersXLSX(f, sheetName, locale, data.([]SurveyAnswerExport)); err != nil {
+			return nil, err
+		}
+	case ReportTypePayments:
+		if err := s.writePaymentsXLSX(f, sheetName, locale, data.([]PaymentExport)); err != nil {
 			return nil, err
 		}
 	}
@@ -535,9 +781,10 @@ func (s *Service) generateXLSX(reportType ReportType, data interface{}) ([]byte,
 	return buf.Bytes(), nil
 }
 
-func (s *Service) writeTransactionsXLSX(f *excelize.File, sheet string, data []TransactionExport) error {
+func (s *Service) writeTransactionsXLSX(f *excelize.File, sheet, locale string, data []TransactionExport) error {
 	headers := []interface{}{"ID", "Wallet ID", "User Email", "User Name", "Type", "Amount", "Amount Display",
-		"Balance Before", "Balance After", "Reference", "Stand ID", "Stand Name", "Staff ID", "Staff Name", "Status", "Created At"}
+		"Balance Before", "Balance After", "Reference", "Stand ID", "Stand Name", "Staff ID", "Staff Name", "Status", "Source", "Created At"}
+	headers = localizeHeaderRow(locale, headers)
 	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
 		return err
 	}
@@ -567,6 +814,7 @@ func (s *Service) writeTransactionsXLSX(f *excelize.File, sheet string, data []T
 			uuidPtrToString(row.StaffID),
 			row.StaffName,
 			row.Status,
+			row.Source,
 			row.CreatedAt.Format(time.RFC3339),
 		}
 		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowNum), &values); err != nil {
@@ -577,8 +825,9 @@ func (s *Service) writeTransactionsXLSX(f *excelize.File, sheet string, data []T
 	return nil
 }
 
-func (s *Service) writeSalesXLSX(f *excelize.File, sheet string, data []SalesExport) error {
+func (s *Service) writeSalesXLSX(f *excelize.File, sheet, locale string, data []SalesExport) error {
 	headers := []interface{}{"Stand ID", "Stand Name", "Product ID", "Product Name", "Quantity", "Unit Price", "Total Revenue", "Revenue Display", "Date"}
+	headers = localizeHeaderRow(locale, headers)
 	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
 		return err
 	}
@@ -610,9 +859,10 @@ func (s *Service) writeSalesXLSX(f *excelize.File, sheet string, data []SalesExp
 	return nil
 }
 
-func (s *Service) writeTicketsXLSX(f *excelize.File, sheet string, data []TicketExport) error {
+func (s *Service) writeTicketsXLSX(f *excelize.File, sheet, locale string, data []TicketExport) error {
 	headers := []interface{}{"ID", "Code", "Ticket Type ID", "Ticket Type", "Price", "Price Display",
 		"Holder Name", "Holder Email", "User ID", "Status", "Checked In At", "Checked In By", "Created At"}
+	headers = localizeHeaderRow(locale, headers)
 	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
 		return err
 	}
@@ -652,9 +902,10 @@ func (s *Service) writeTicketsXLSX(f *excelize.File, sheet string, data []Ticket
 	return nil
 }
 
-func (s *Service) writeWalletsXLSX(f *excelize.File, sheet string, data []WalletExport) error {
+func (s *Service) writeWalletsXLSX(f *excelize.File, sheet, locale string, data []WalletExport) error {
 	headers := []interface{}{"ID", "User ID", "User Email", "User Name", "Balance", "Balance Display",
-		"Status", "Total Top Ups", "Total Purchases", "Transaction Count", "Created At"}
+		"Status", "Total Top Ups", "Total Purchases", "Transaction Count", "Source", "Created At"}
+	headers = localizeHeaderRow(locale, headers)
 	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
 		return err
 	}
@@ -669,7 +920,7 @@ func (s *Service) writeWalletsXLSX(f *excelize.File, sheet string, data []Wallet
 		rowNum := i + 2
 		values := []interface{}{
 			row.ID.String(),
-			row.UserID.String(),
+			uuidPtrToString(row.UserID),
 			row.UserEmail,
 			row.UserName,
 			row.Balance,
@@ -678,6 +929,7 @@ func (s *Service) writeWalletsXLSX(f *excelize.File, sheet string, data []Wallet
 			row.TotalTopUps,
 			row.TotalPurchases,
 			row.TransactionCount,
+			row.Source,
 			row.CreatedAt.Format(time.RFC3339),
 		}
 		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowNum), &values); err != nil {
@@ -688,10 +940,11 @@ func (s *Service) writeWalletsXLSX(f *excelize.File, sheet string, data []Wallet
 	return nil
 }
 
-func (s *Service) writeStaffPerformanceXLSX(f *excelize.File, sheet string, data []StaffPerformanceExport) error {
+func (s *Service) writeStaffPerformanceXLSX(f *excelize.File, sheet, locale string, data []StaffPerformanceExport) error {
 	headers := []interface{}{"Staff ID", "Staff Name", "Staff Email", "Stand ID", "Stand Name",
 		"Transactions", "Total Amount", "Amount Display", "Average Amount", "Avg Amount Display",
 		"Top Ups", "Purchases", "Refunds"}
+	headers = localizeHeaderRow(locale, headers)
 	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
 		return err
 	}
@@ -727,36 +980,152 @@ func (s *Service) writeStaffPerformanceXLSX(f *excelize.File, sheet string, data
 	return nil
 }
 
+func (s *Service) writeStandFeedbackXLSX(f *excelize.File, sheet, locale string, data []StandFeedbackExport) error {
+	headers := []interface{}{"Feedback ID", "Stand ID", "Stand Name", "Order ID", "Rating", "Comment", "Status", "Created At"}
+	headers = localizeHeaderRow(locale, headers)
+	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return err
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+	})
+	f.SetRowStyle(sheet, 1, 1, headerStyle)
+
+	for i, row := range data {
+		rowNum := i + 2
+		values := []interface{}{
+			row.FeedbackID.String(),
+			row.StandID.String(),
+			row.StandName,
+			row.OrderID.String(),
+			row.Rating,
+			row.Comment,
+			row.Status,
+			row.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowNum), &values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) writeSurveyAnswersXLSX(f *excelize.File, sheet, locale string, data []SurveyAnswerExport) error {
+	headers := []interface{}{"Submission ID", "Survey ID", "Survey Title", "Question #", "Question", "Question Type", "Answer", "Submitted At"}
+	headers = localizeHeaderRow(locale, headers)
+	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return err
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+	})
+	f.SetRowStyle(sheet, 1, 1, headerStyle)
+
+	for i, row := range data {
+		rowNum := i + 2
+		values := []interface{}{
+			row.SubmissionID.String(),
+			row.SurveyID.String(),
+			row.SurveyTitle,
+			row.QuestionPosition,
+			row.QuestionPrompt,
+			row.QuestionType,
+			row.Answer,
+			row.SubmittedAt.Format("2006-01-02 15:04:05"),
+		}
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowNum), &values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) writePaymentsXLSX(f *excelize.File, sheet, locale string, data []PaymentExport) error {
+	headers := []interface{}{"ID", "Stripe Intent ID", "Kind", "Customer Email", "Amount", "Ticketing", "Cashless",
+		"Platform Fee", "Refunded Ticketing", "Refunded Cashless", "Status", "Completed At", "Created At"}
+	headers = localizeHeaderRow(locale, headers)
+	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return err
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+	})
+	f.SetRowStyle(sheet, 1, 1, headerStyle)
+
+	for i, row := range data {
+		rowNum := i + 2
+		completedAt := ""
+		if row.CompletedAt != nil {
+			completedAt = row.CompletedAt.Format("2006-01-02 15:04:05")
+		}
+		values := []interface{}{
+			row.ID.String(),
+			row.StripeIntentID,
+			row.Kind,
+			row.CustomerEmail,
+			row.Amount,
+			row.TicketAmount,
+			row.CreditAmount,
+			row.PlatformFee,
+			row.RefundedTicketAmount,
+			row.RefundedCreditAmount,
+			row.Status,
+			completedAt,
+			row.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowNum), &values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // generatePDF generates a PDF file from the data using gofpdf
-func (s *Service) generatePDF(reportType ReportType, data interface{}) ([]byte, error) {
+func (s *Service) generatePDF(reportType ReportType, locale string, data interface{}) ([]byte, error) {
 	pdf := gofpdf.New("L", "mm", "A4", "") // Landscape for wider tables
 	pdf.SetFont("Arial", "", 10)
 	pdf.AddPage()
 
 	// Add title
 	pdf.SetFont("Arial", "B", 16)
-	title := s.getReportTitle(reportType)
-	pdf.Cell(0, 10, title)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	title := s.getReportTitle(reportType, locale)
+	pdf.Cell(0, 10, tr(title))
 	pdf.Ln(15)
 
 	// Add generation timestamp
 	pdf.SetFont("Arial", "", 8)
-	pdf.Cell(0, 5, fmt.Sprintf("Generated: %s", time.Now().Format("2006-01-02 15:04:05")))
+	pdf.Cell(0, 5, tr(i18n.T(locale, "report.generated", i18n.Params{"date": time.Now().Format("2006-01-02 15:04:05")})))
 	pdf.Ln(10)
 
 	pdf.SetFont("Arial", "", 8)
 
 	switch reportType {
 	case ReportTypeTransactions:
-		s.writeTransactionsPDF(pdf, data.([]TransactionExport))
+		s.writeTransactionsPDF(pdf, locale, data.([]TransactionExport))
 	case ReportTypeSales:
-		s.writeSalesPDF(pdf, data.([]SalesExport))
+		s.writeSalesPDF(pdf, locale, data.([]SalesExport))
 	case ReportTypeTickets:
-		s.writeTicketsPDF(pdf, data.([]TicketExport))
+		s.writeTicketsPDF(pdf, locale, data.([]TicketExport))
 	case ReportTypeWallets:
-		s.writeWalletsPDF(pdf, data.([]WalletExport))
+		s.writeWalletsPDF(pdf, locale, data.([]WalletExport))
 	case ReportTypeStaffPerformance:
-		s.writeStaffPerformancePDF(pdf, data.([]StaffPerformanceExport))
+		s.writeStaffPerformancePDF(pdf, locale, data.([]StaffPerformanceExport))
+	case ReportTypeStandFeedback:
+		s.writeStandFeedbackPDF(pdf, locale, data.([]StandFeedbackExport))
+	case ReportTypeSurveyResponses:
+		s.writeSurveyAnswersPDF(pdf, locale, data.([]SurveyAnswerExport))
+	case ReportTypePayments:
+		s.writePaymentsPDF(pdf, locale, data.([]PaymentExport))
 	}
 
 	var buf bytes.Buffer
@@ -767,26 +1136,53 @@ func (s *Service) generatePDF(reportType ReportType, data interface{}) ([]byte,
 	return buf.Bytes(), nil
 }
 
-func (s *Service) getReportTitle(reportType ReportType) string {
-	switch reportType {
-	case ReportTypeTransactions:
-		return "Transactions Report"
-	case ReportTypeSales:
-		return "Sales Report"
-	case ReportTypeTickets:
-		return "Tickets Report"
-	case ReportTypeWallets:
-		return "Wallets Report"
-	case ReportTypeStaffPerformance:
-		return "Staff Performance Report"
-	default:
-		return "Report"
+func (s *Service) getReportTitle(reportType ReportType, locale string) string {
+	if title, ok := i18n.Lookup(locale, "report.title."+string(reportType)); ok {
+		return title
+	}
+	return i18n.T(locale, "report.title.default")
+}
+
+// localizeHeaders translates column headers, which are catalog keys by their English label
+func localizeHeaders(locale string, headers []string) []string {
+	localized := make([]string, len(headers))
+	for i, header := range headers {
+		localized[i] = i18n.T(locale, "report.column."+columnKey(header))
+	}
+	return localized
+}
+
+// localizeHeaderRow translates the header row of a spreadsheet
+func localizeHeaderRow(locale string, headers []interface{}) []interface{} {
+	localized := make([]interface{}, len(headers))
+	for i, header := range headers {
+		localized[i] = i18n.T(locale, "report.column."+columnKey(fmt.Sprint(header)))
 	}
+	return localized
 }
 
-func (s *Service) writeTransactionsPDF(pdf *gofpdf.Fpdf, data []TransactionExport) {
+// localizePDFHeaders translates column headers to the code page of the PDF core fonts
+func localizePDFHeaders(pdf *gofpdf.Fpdf, locale string, headers []string) []string {
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	localized := localizeHeaders(locale, headers)
+	for i, header := range localized {
+		localized[i] = tr(header)
+	}
+	return localized
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// columnKey turns a column label like "Question #" into its catalog key "question_no"
+func columnKey(label string) string {
+	label = strings.ToLower(strings.ReplaceAll(label, "#", "no"))
+	return strings.Trim(nonAlphanumeric.ReplaceAllString(label, "_"), "_")
+}
+
+func (s *Service) writeTransactionsPDF(pdf *gofpdf.Fpdf, locale string, data []TransactionExport) {
 	// Headers
 	headers := []string{"ID", "User", "Type", "Amount", "Stand", "Staff", "Status", "Date"}
+	headers = localizePDFHeaders(pdf, locale, headers)
 	widths := []float64{30, 40, 20, 25, 35, 35, 20, 35}
 
 	pdf.SetFont("Arial", "B", 8)
@@ -830,8 +1226,9 @@ func (s *Service) writeTransactionsPDF(pdf *gofpdf.Fpdf, data []TransactionExpor
 	}
 }
 
-func (s *Service) writeSalesPDF(pdf *gofpdf.Fpdf, data []SalesExport) {
+func (s *Service) writeSalesPDF(pdf *gofpdf.Fpdf, locale string, data []SalesExport) {
 	headers := []string{"Stand", "Product", "Quantity", "Unit Price", "Total Revenue", "Date"}
+	headers = localizePDFHeaders(pdf, locale, headers)
 	widths := []float64{50, 60, 25, 30, 35, 40}
 
 	pdf.SetFont("Arial", "B", 8)
@@ -862,8 +1259,9 @@ func (s *Service) writeSalesPDF(pdf *gofpdf.Fpdf, data []SalesExport) {
 	}
 }
 
-func (s *Service) writeTicketsPDF(pdf *gofpdf.Fpdf, data []TicketExport) {
+func (s *Service) writeTicketsPDF(pdf *gofpdf.Fpdf, locale string, data []TicketExport) {
 	headers := []string{"Code", "Type", "Price", "Holder", "Email", "Status", "Checked In", "Created"}
+	headers = localizePDFHeaders(pdf, locale, headers)
 	widths := []float64{25, 30, 25, 35, 45, 20, 35, 35}
 
 	pdf.SetFont("Arial", "B", 8)
@@ -900,8 +1298,9 @@ func (s *Service) writeTicketsPDF(pdf *gofpdf.Fpdf, data []TicketExport) {
 	}
 }
 
-func (s *Service) writeWalletsPDF(pdf *gofpdf.Fpdf, data []WalletExport) {
+func (s *Service) writeWalletsPDF(pdf *gofpdf.Fpdf, locale string, data []WalletExport) {
 	headers := []string{"User", "Email", "Balance", "Status", "Top Ups", "Purchases", "Transactions", "Created"}
+	headers = localizePDFHeaders(pdf, locale, headers)
 	widths := []float64{35, 50, 25, 20, 25, 25, 25, 35}
 
 	pdf.SetFont("Arial", "B", 8)
@@ -934,8 +1333,9 @@ func (s *Service) writeWalletsPDF(pdf *gofpdf.Fpdf, data []WalletExport) {
 	}
 }
 
-func (s *Service) writeStaffPerformancePDF(pdf *gofpdf.Fpdf, data []StaffPerformanceExport) {
+func (s *Service) writeStaffPerformancePDF(pdf *gofpdf.Fpdf, locale string, data []StaffPerformanceExport) {
 	headers := []string{"Staff", "Stand", "Transactions", "Total Amount", "Avg Amount", "Top Ups", "Purchases", "Refunds"}
+	headers = localizePDFHeaders(pdf, locale, headers)
 	widths := []float64{40, 40, 25, 30, 30, 25, 25, 25}
 
 	pdf.SetFont("Arial", "B", 8)
@@ -968,6 +1368,106 @@ func (s *Service) writeStaffPerformancePDF(pdf *gofpdf.Fpdf, data []StaffPerform
 	}
 }
 
+func (s *Service) writeStandFeedbackPDF(pdf *gofpdf.Fpdf, locale string, data []StandFeedbackExport) {
+	headers := []string{"Stand", "Rating", "Comment", "Status", "Date"}
+	headers = localizePDFHeaders(pdf, locale, headers)
+	widths := []float64{45, 20, 140, 30, 35}
+
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(68, 114, 196)
+	pdf.SetTextColor(255, 255, 255)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFillColor(240, 240, 240)
+
+	for i, row := range data {
+		fill := i%2 == 0
+		pdf.CellFormat(widths[0], 6, truncateString(row.StandName, 28), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[1], 6, fmt.Sprintf("%d/5", row.Rating), "1", 0, "C", fill, 0, "")
+		pdf.CellFormat(widths[2], 6, truncateString(row.Comment, 95), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[3], 6, row.Status, "1", 0, "C", fill, 0, "")
+		pdf.CellFormat(widths[4], 6, row.CreatedAt.Format("2006-01-02 15:04"), "1", 0, "C", fill, 0, "")
+		pdf.Ln(-1)
+
+		if pdf.GetY() > 180 {
+			pdf.AddPage()
+		}
+	}
+}
+
+func (s *Service) writeSurveyAnswersPDF(pdf *gofpdf.Fpdf, locale string, data []SurveyAnswerExport) {
+	headers := []string{"Survey", "#", "Question", "Answer", "Submitted"}
+	headers = localizePDFHeaders(pdf, locale, headers)
+	widths := []float64{45, 10, 80, 100, 35}
+
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(68, 114, 196)
+	pdf.SetTextColor(255, 255, 255)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFillColor(240, 240, 240)
+
+	for i, row := range data {
+		fill := i%2 == 0
+		pdf.CellFormat(widths[0], 6, truncateString(row.SurveyTitle, 28), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[1], 6, fmt.Sprintf("%d", row.QuestionPosition), "1", 0, "C", fill, 0, "")
+		pdf.CellFormat(widths[2], 6, truncateString(row.QuestionPrompt, 55), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[3], 6, truncateString(row.Answer, 70), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[4], 6, row.SubmittedAt.Format("2006-01-02 15:04"), "1", 0, "C", fill, 0, "")
+		pdf.Ln(-1)
+
+		if pdf.GetY() > 180 {
+			pdf.AddPage()
+		}
+	}
+}
+
+func (s *Service) writePaymentsPDF(pdf *gofpdf.Fpdf, locale string, data []PaymentExport) {
+	headers := []string{"Created", "Kind", "Email", "Amount", "Ticketing", "Cashless", "Refunded Ticketing", "Refunded Cashless", "Status"}
+	headers = localizePDFHeaders(pdf, locale, headers)
+	widths := []float64{32, 20, 55, 22, 22, 22, 30, 30, 32}
+
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(68, 114, 196)
+	pdf.SetTextColor(255, 255, 255)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFillColor(240, 240, 240)
+
+	for i, row := range data {
+		fill := i%2 == 0
+		pdf.CellFormat(widths[0], 6, row.CreatedAt.Format("2006-01-02 15:04"), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[1], 6, row.Kind, "1", 0, "C", fill, 0, "")
+		pdf.CellFormat(widths[2], 6, truncateString(row.CustomerEmail, 34), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[3], 6, formatCurrency(row.Amount), "1", 0, "R", fill, 0, "")
+		pdf.CellFormat(widths[4], 6, formatCurrency(row.TicketAmount), "1", 0, "R", fill, 0, "")
+		pdf.CellFormat(widths[5], 6, formatCurrency(row.CreditAmount), "1", 0, "R", fill, 0, "")
+		pdf.CellFormat(widths[6], 6, formatCurrency(row.RefundedTicketAmount), "1", 0, "R", fill, 0, "")
+		pdf.CellFormat(widths[7], 6, formatCurrency(row.RefundedCreditAmount), "1", 0, "R", fill, 0, "")
+		pdf.CellFormat(widths[8], 6, row.Status, "1", 0, "C", fill, 0, "")
+		pdf.Ln(-1)
+
+		if pdf.GetY() > 180 {
+			pdf.AddPage()
+		}
+	}
+}
+
 // Helper functions
 
 func uuidPtrToString(id *uuid.UUID) string {
@@ -0,0 +1,184 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+)
+
+// defaultStatementColor is the header color of statements of festivals without branding
+const defaultStatementColor = "#4472C4"
+
+// Branding is the festival identity printed on documents rendered for attendees
+type Branding struct {
+	FestivalName string
+	CurrencyName string
+	Timezone     string // Dates are printed in the festival timezone
+	PrimaryColor string // #RRGGBB
+}
+
+// WalletStatement is the statement of an attendee's wallet over a period
+type WalletStatement struct {
+	Branding       Branding
+	WalletID       uuid.UUID
+	HolderEmail    string
+	From           time.Time
+	To             time.Time
+	OpeningBalance int64 // In cents
+	ClosingBalance int64
+	TotalCredited  int64
+	TotalDebited   int64 // Positive
+	Lines          []StatementLine
+}
+
+// StatementLine is a transaction of a wallet statement
+type StatementLine struct {
+	Date        time.Time
+	Type        string // Wallet transaction type
+	Description string
+	Reference   string
+	Amount      int64 // Positive for credits, negative for debits
+	Balance     int64 // After the transaction
+}
+
+// RenderWalletStatement renders a wallet statement as a PDF in the holder's locale
+func (s *Service) RenderWalletStatement(statement *WalletStatement, locale string) ([]byte, error) {
+	loc, err := time.LoadLocation(statement.Branding.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	currency := statement.Branding.CurrencyName
+	amount := func(cents int64) string { return i18n.FormatAmount(locale, cents, currency) }
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	r, g, b := hexColor(statement.Branding.PrimaryColor)
+
+	// Branded header band
+	pdf.AddPage()
+	pdf.SetFillColor(r, g, b)
+	pdf.Rect(0, 0, 210, 28, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Arial", "B", 18)
+	pdf.SetXY(10, 8)
+	pdf.Cell(0, 8, tr(statement.Branding.FestivalName))
+	pdf.SetFont("Arial", "", 11)
+	pdf.SetXY(10, 17)
+	pdf.Cell(0, 6, tr(i18n.T(locale, "statement.title")))
+
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(10, 36)
+	pdf.SetFont("Arial", "", 9)
+	details := [][2]string{
+		{i18n.T(locale, "statement.holder"), statement.HolderEmail},
+		{i18n.T(locale, "statement.wallet"), statement.WalletID.String()},
+		{i18n.T(locale, "statement.period"), i18n.T(locale, "statement.period_range", i18n.Params{
+			"from": i18n.FormatDate(locale, statement.From.In(loc)),
+			"to":   i18n.FormatDate(locale, statement.To.In(loc)),
+		})},
+	}
+	for _, d := range details {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.CellFormat(35, 6, tr(d[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(0, 6, tr(d[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Summary
+	summary := [][2]string{
+		{i18n.T(locale, "statement.opening_balance"), amount(statement.OpeningBalance)},
+		{i18n.T(locale, "statement.total_credited"), amount(statement.TotalCredited)},
+		{i18n.T(locale, "statement.total_debited"), amount(-statement.TotalDebited)},
+		{i18n.T(locale, "statement.closing_balance"), amount(statement.ClosingBalance)},
+	}
+	pdf.SetFillColor(245, 245, 245)
+	for i, row := range summary {
+		pdf.SetFont("Arial", "", 9)
+		if i == len(summary)-1 {
+			pdf.SetFont("Arial", "B", 9)
+		}
+		pdf.CellFormat(60, 7, tr(row[0]), "1", 0, "L", true, 0, "")
+		pdf.CellFormat(40, 7, tr(row[1]), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	// Transactions
+	headers := []string{
+		i18n.T(locale, "report.column.date"),
+		i18n.T(locale, "report.column.type"),
+		i18n.T(locale, "report.column.description"),
+		i18n.T(locale, "report.column.amount"),
+		i18n.T(locale, "report.column.balance"),
+	}
+	widths := []float64{38, 30, 62, 30, 30}
+	writeHeader := func() {
+		pdf.SetFont("Arial", "B", 8)
+		pdf.SetFillColor(r, g, b)
+		pdf.SetTextColor(255, 255, 255)
+		for i, header := range headers {
+			pdf.CellFormat(widths[i], 7, tr(header), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 8)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFillColor(240, 240, 240)
+	}
+	writeHeader()
+
+	if len(statement.Lines) == 0 {
+		pdf.CellFormat(190, 7, tr(i18n.T(locale, "statement.no_transactions")), "1", 1, "C", false, 0, "")
+	}
+	for i, line := range statement.Lines {
+		description := line.Description
+		if description == "" {
+			description = line.Reference
+		}
+		fill := i%2 == 0
+		pdf.CellFormat(widths[0], 6, line.Date.In(loc).Format("2006-01-02 15:04"), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[1], 6, tr(transactionTypeLabel(locale, line.Type)), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[2], 6, tr(truncateString(description, 40)), "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(widths[3], 6, tr(amount(line.Amount)), "1", 0, "R", fill, 0, "")
+		pdf.CellFormat(widths[4], 6, tr(amount(line.Balance)), "1", 0, "R", fill, 0, "")
+		pdf.Ln(-1)
+
+		if pdf.GetY() > 270 && i < len(statement.Lines)-1 {
+			pdf.AddPage()
+			writeHeader()
+		}
+	}
+
+	pdf.Ln(6)
+	pdf.SetFont("Arial", "", 7)
+	pdf.SetTextColor(120, 120, 120)
+	pdf.Cell(0, 5, tr(i18n.T(locale, "report.generated", i18n.Params{"date": i18n.FormatDateTime(locale, time.Now().In(loc))})))
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate statement PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// transactionTypeLabel returns the localized label of a wallet transaction type
+func transactionTypeLabel(locale, transactionType string) string {
+	if label, ok := i18n.Lookup(locale, "statement.type."+transactionType); ok {
+		return label
+	}
+	return transactionType
+}
+
+// hexColor parses a #RRGGBB color, falling back to the default statement color
+func hexColor(color string) (int, int, int) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(color, "#")) != 6 {
+		value, _ = strconv.ParseUint(strings.TrimPrefix(defaultStatementColor, "#"), 16, 32)
+	}
+	return int(value >> 16 & 0xFF), int(value >> 8 & 0xFF), int(value & 0xFF)
+}
@@ -0,0 +1,55 @@
+package residency
+
+// CatalogConfig describes the providers configured for the deployment; empty
+// endpoints are providers that are not configured
+type CatalogConfig struct {
+	StorageEndpoint   string
+	StorageBucket     string
+	StorageRegion     string
+	EUStorageEndpoint string
+	EUStorageBucket   string
+	EUStorageRegion   string
+
+	PostalURL      string
+	PostalRegion   string
+	PostalEUURL    string
+	PostalEURegion string
+
+	TwilioEnabled bool
+	TwilioHost    string
+	TwilioRegion  string
+}
+
+// NewCatalog lists the configured providers, the ones reserved for festivals pinned to
+// the EU after the default ones
+func NewCatalog(cfg CatalogConfig) Catalog {
+	var providers []Provider
+	if cfg.StorageEndpoint != "" {
+		providers = append(providers, Provider{
+			ID: ProviderMinio, Kind: KindStorage, Endpoint: cfg.StorageEndpoint,
+			Region: cfg.StorageRegion, Bucket: cfg.StorageBucket,
+		})
+	}
+	if cfg.EUStorageEndpoint != "" {
+		providers = append(providers, Provider{
+			ID: ProviderMinioEU, Kind: KindStorage, Endpoint: cfg.EUStorageEndpoint,
+			Region: cfg.EUStorageRegion, Bucket: cfg.EUStorageBucket, Reserved: true,
+		})
+	}
+	if cfg.PostalURL != "" {
+		providers = append(providers, Provider{
+			ID: ProviderPostal, Kind: KindEmail, Endpoint: cfg.PostalURL, Region: cfg.PostalRegion,
+		})
+	}
+	if cfg.PostalEUURL != "" {
+		providers = append(providers, Provider{
+			ID: ProviderPostalEU, Kind: KindEmail, Endpoint: cfg.PostalEUURL, Region: cfg.PostalEURegion, Reserved: true,
+		})
+	}
+	if cfg.TwilioEnabled {
+		providers = append(providers, Provider{
+			ID: ProviderTwilio, Kind: KindSMS, Endpoint: cfg.TwilioHost, Region: cfg.TwilioRegion,
+		})
+	}
+	return Catalog{Providers: providers}
+}
@@ -0,0 +1,140 @@
+package residency
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped residency routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	residency := r.Group("/residency")
+	{
+		residency.GET("", h.GetPolicy)
+		residency.PUT("", h.UpdatePolicy)
+		residency.GET("/audit", h.GetAudit)
+	}
+}
+
+// GetPolicy returns the data residency policy
+// @Summary Get residency policy
+// @Description Get where the data of the festival may be stored and processed; STANDARD when no policy was set
+// @Tags residency
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Policy} "Residency policy"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/residency [get]
+func (h *Handler) GetPolicy(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	policy, err := h.service.GetPolicy(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, policy)
+}
+
+// UpdatePolicy sets the data residency policy
+// @Summary Update residency policy
+// @Description Pin the storage buckets, report delivery, email and SMS providers of the festival to EU regions, or release them. EU_ONLY is refused while a configured provider is outside the EU.
+// @Tags residency
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body UpdatePolicyRequest true "Residency policy"
+// @Success 200 {object} response.Response{data=Policy} "Residency policy updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid mode or providers outside the EU"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/residency [put]
+func (h *Handler) UpdatePolicy(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req UpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, policy)
+}
+
+// GetAudit returns the data flow audit report
+// @Summary Audit data flows
+// @Description List the providers the data of the festival is sent to, with their region and whether the residency policy is met
+// @Tags residency
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=Report} "Data flow audit"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/residency/audit [get]
+func (h *Handler) GetAudit(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	report, err := h.service.Audit(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	var policyErr *PolicyError
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrInvalidMode):
+		response.BadRequest(c, "INVALID_MODE", err.Error(), nil)
+	case errors.As(err, &policyErr):
+		response.BadRequest(c, "PROVIDER_OUTSIDE_EU", "The configured providers cannot keep the festival data in the EU", policyErr.Violations)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
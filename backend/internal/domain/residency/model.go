@@ -0,0 +1,147 @@
+package residency
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Residency errors
+var (
+	ErrFestivalNotFound  = errors.New("festival not found")
+	ErrInvalidMode       = errors.New("residency mode must be STANDARD or EU_ONLY")
+	ErrProviderOutsideEU = errors.New("provider is outside the EU")
+)
+
+// Mode is where the data of a festival may be stored and processed
+type Mode string
+
+const (
+	ModeStandard Mode = "STANDARD" // Default providers, wherever they are
+	ModeEUOnly   Mode = "EU_ONLY"  // Storage, report delivery, email and SMS in EU regions only
+)
+
+// IsValid checks if the mode is valid
+func (m Mode) IsValid() bool {
+	return m == ModeStandard || m == ModeEUOnly
+}
+
+// Kind is what a provider is used for
+type Kind string
+
+const (
+	KindStorage Kind = "STORAGE"
+	KindEmail   Kind = "EMAIL"
+	KindSMS     Kind = "SMS"
+)
+
+// Provider IDs, as configured in the environment
+const (
+	ProviderMinio    = "minio"
+	ProviderMinioEU  = "minio-eu"
+	ProviderPostal   = "postal"
+	ProviderPostalEU = "postal-eu"
+	ProviderTwilio   = "twilio"
+)
+
+// Provider is a configured storage or third-party service festival data is sent to
+type Provider struct {
+	ID       string `json:"id"`
+	Kind     Kind   `json:"kind"`
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region,omitempty"` // Empty when not declared, which is treated as outside the EU
+	Bucket   string `json:"bucket,omitempty"`
+	Reserved bool   `json:"reserved"` // Only used for festivals pinned to the EU
+}
+
+// InEU reports whether the provider is declared in an EU region
+func (p Provider) InEU() bool {
+	return IsEURegion(p.Region)
+}
+
+// Regions whose code does not tell whether they are in the EU
+var (
+	twilioEURegions = map[string]bool{"ie1": true, "de1": true}
+	europeNonEU     = map[string]bool{"eu-west-2": true, "eu-central-2": true} // London and Zurich
+)
+
+// IsEURegion reports whether a region code is located in the EU: AWS and MinIO style
+// codes such as eu-west-3, and the Twilio ie1 and de1 regions
+func IsEURegion(region string) bool {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if twilioEURegions[region] {
+		return true
+	}
+	return strings.HasPrefix(region, "eu-") && !europeNonEU[region]
+}
+
+// Catalog is the set of providers configured for the deployment
+type Catalog struct {
+	Providers []Provider
+}
+
+// Policy is the data residency policy of a festival; festivals without one are STANDARD
+type Policy struct {
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;primary_key"`
+	Mode       Mode       `json:"mode" gorm:"not null;default:'STANDARD'"`
+	Notes      string     `json:"notes,omitempty"`
+	UpdatedBy  *uuid.UUID `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (Policy) TableName() string {
+	return "festival_residency_policies"
+}
+
+// UpdatePolicyRequest represents the request to set the residency policy of a festival
+type UpdatePolicyRequest struct {
+	Mode  Mode   `json:"mode" binding:"required"`
+	Notes string `json:"notes" binding:"max=500"`
+}
+
+// PolicyError lists why the configured providers cannot serve a policy
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "providers outside the EU: " + strings.Join(e.Violations, "; ")
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrProviderOutsideEU
+}
+
+// DataCategory is a kind of festival data leaving the API
+type DataCategory string
+
+const (
+	DataReportFiles DataCategory = "REPORT_FILES" // Generated reports and their download links
+	DataEmail       DataCategory = "EMAIL"        // Transactional emails and report notifications
+	DataSMS         DataCategory = "SMS"          // Text messages to attendees and staff
+)
+
+// Flow is where one category of festival data is sent
+type Flow struct {
+	Data      DataCategory `json:"data"`
+	Kind      Kind         `json:"kind"`
+	Provider  string       `json:"provider"`
+	Endpoint  string       `json:"endpoint"`
+	Region    string       `json:"region,omitempty"`
+	Bucket    string       `json:"bucket,omitempty"`
+	InEU      bool         `json:"inEu"`
+	Compliant bool         `json:"compliant"` // Allowed by the policy of the festival
+}
+
+// Report audits where the data of a festival flows against its policy
+type Report struct {
+	FestivalID  uuid.UUID `json:"festivalId"`
+	Mode        Mode      `json:"mode"`
+	Compliant   bool      `json:"compliant"`
+	Flows       []Flow    `json:"flows"`
+	Violations  []string  `json:"violations"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
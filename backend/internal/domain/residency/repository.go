@@ -0,0 +1,57 @@
+package residency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	GetPolicy(ctx context.Context, festivalID uuid.UUID) (*Policy, error)
+	SavePolicy(ctx context.Context, policy *Policy) error
+	FestivalExists(ctx context.Context, festivalID uuid.UUID) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetPolicy(ctx context.Context, festivalID uuid.UUID) (*Policy, error) {
+	var policy Policy
+	err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).First(&policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get residency policy: %w", err)
+	}
+	return &policy, nil
+}
+
+func (r *repository) SavePolicy(ctx context.Context, policy *Policy) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "festival_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mode", "notes", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+	if err != nil {
+		return fmt.Errorf("failed to save residency policy: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) FestivalExists(ctx context.Context, festivalID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("public.festivals").Where("id = ?", festivalID).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check festival: %w", err)
+	}
+	return count > 0, nil
+}
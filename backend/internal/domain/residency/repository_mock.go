@@ -0,0 +1,38 @@
+package residency
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) GetPolicy(ctx context.Context, festivalID uuid.UUID) (*Policy, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Policy), args.Error(1)
+}
+
+func (m *MockRepository) SavePolicy(ctx context.Context, policy *Policy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FestivalExists(ctx context.Context, festivalID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,201 @@
+package residency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// flows are the categories of festival data sent to providers, with the kind of
+// provider each goes through
+var flows = []struct {
+	data DataCategory
+	kind Kind
+}{
+	{DataReportFiles, KindStorage},
+	{DataEmail, KindEmail},
+	{DataSMS, KindSMS},
+}
+
+// Service manages the data residency policies of festivals and routes their data to
+// the providers the policies allow
+type Service struct {
+	repo    Repository
+	catalog Catalog
+	now     func() time.Time
+}
+
+// NewService creates a residency service over the providers configured for the deployment
+func NewService(repo Repository, catalog Catalog) *Service {
+	return &Service{
+		repo:    repo,
+		catalog: catalog,
+		now:     time.Now,
+	}
+}
+
+// GetPolicy returns the residency policy of a festival, STANDARD when none was set
+func (s *Service) GetPolicy(ctx context.Context, festivalID uuid.UUID) (*Policy, error) {
+	exists, err := s.repo.FestivalExists(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrFestivalNotFound
+	}
+	return s.policy(ctx, festivalID)
+}
+
+// UpdatePolicy sets the residency policy of a festival. EU_ONLY is refused unless every
+// configured kind of provider can be served from the EU.
+func (s *Service) UpdatePolicy(ctx context.Context, festivalID uuid.UUID, req UpdatePolicyRequest, userID *uuid.UUID) (*Policy, error) {
+	if !req.Mode.IsValid() {
+		return nil, ErrInvalidMode
+	}
+	exists, err := s.repo.FestivalExists(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrFestivalNotFound
+	}
+
+	if violations := s.violations(req.Mode); len(violations) > 0 {
+		return nil, &PolicyError{Violations: violations}
+	}
+
+	policy, err := s.policy(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if policy.CreatedAt.IsZero() {
+		policy.CreatedAt = now
+	}
+	policy.Mode = req.Mode
+	policy.Notes = req.Notes
+	policy.UpdatedBy = userID
+	policy.UpdatedAt = now
+
+	if err := s.repo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Route returns the provider a festival's data of a kind must be sent to, nil when no
+// provider of the kind is configured. Festivals pinned to the EU get the reserved EU
+// provider of the kind when there is one, and ErrProviderOutsideEU when the only
+// provider is outside the EU.
+func (s *Service) Route(ctx context.Context, festivalID uuid.UUID, kind Kind) (*Provider, error) {
+	policy, err := s.policy(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return s.route(kind, policy.Mode)
+}
+
+// Audit reports where the data of a festival flows and whether its policy is met, e.g.
+// after a provider was reconfigured outside the EU
+func (s *Service) Audit(ctx context.Context, festivalID uuid.UUID) (*Report, error) {
+	policy, err := s.GetPolicy(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		FestivalID:  festivalID,
+		Mode:        policy.Mode,
+		Compliant:   true,
+		Flows:       []Flow{},
+		Violations:  []string{},
+		GeneratedAt: s.now(),
+	}
+	for _, f := range flows {
+		provider, violation := s.resolve(f.kind, policy.Mode)
+		if violation != "" {
+			// Reported against the provider the data would otherwise go to
+			provider, _ = s.resolve(f.kind, ModeStandard)
+			report.Compliant = false
+			report.Violations = append(report.Violations, fmt.Sprintf("%s: %s", f.data, violation))
+		}
+		if provider == nil {
+			continue
+		}
+		report.Flows = append(report.Flows, Flow{
+			Data:      f.data,
+			Kind:      f.kind,
+			Provider:  provider.ID,
+			Endpoint:  provider.Endpoint,
+			Region:    provider.Region,
+			Bucket:    provider.Bucket,
+			InEU:      provider.InEU(),
+			Compliant: violation == "",
+		})
+	}
+	return report, nil
+}
+
+// policy returns the stored policy of a festival or a STANDARD one
+func (s *Service) policy(ctx context.Context, festivalID uuid.UUID) (*Policy, error) {
+	policy, err := s.repo.GetPolicy(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &Policy{FestivalID: festivalID, Mode: ModeStandard}
+	}
+	return policy, nil
+}
+
+// violations lists the kinds of providers that cannot serve a mode
+func (s *Service) violations(mode Mode) []string {
+	var violations []string
+	for _, kind := range []Kind{KindStorage, KindEmail, KindSMS} {
+		if _, violation := s.resolve(kind, mode); violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+func (s *Service) route(kind Kind, mode Mode) (*Provider, error) {
+	provider, violation := s.resolve(kind, mode)
+	if violation != "" {
+		return nil, fmt.Errorf("%w: %s", ErrProviderOutsideEU, violation)
+	}
+	return provider, nil
+}
+
+// resolve picks the provider of a kind for a mode, or describes why none is allowed
+func (s *Service) resolve(kind Kind, mode Mode) (*Provider, string) {
+	var primary, reserved *Provider
+	for i := range s.catalog.Providers {
+		p := &s.catalog.Providers[i]
+		if p.Kind != kind {
+			continue
+		}
+		if p.Reserved && reserved == nil {
+			reserved = p
+		} else if !p.Reserved && primary == nil {
+			primary = p
+		}
+	}
+
+	if mode != ModeEUOnly {
+		return primary, ""
+	}
+	if reserved != nil && reserved.InEU() {
+		return reserved, ""
+	}
+	if primary != nil && !primary.InEU() {
+		region := primary.Region
+		if region == "" {
+			region = "an undeclared region"
+		}
+		return nil, fmt.Sprintf("%s provider %s is in %s", kind, primary.ID, region)
+	}
+	return primary, ""
+}
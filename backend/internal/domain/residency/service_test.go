@@ -0,0 +1,171 @@
+package residency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// usCatalog is a deployment with US providers only
+func usCatalog() CatalogConfig {
+	return CatalogConfig{
+		StorageEndpoint: "minio.internal:9000",
+		StorageBucket:   "festivals",
+		StorageRegion:   "us-east-1",
+		PostalURL:       "https://postal.example.com",
+		PostalRegion:    "us-east-1",
+		TwilioEnabled:   true,
+		TwilioHost:      "api.twilio.com",
+	}
+}
+
+// euCatalog adds the EU storage and Postal server, and moves Twilio to Ireland
+func euCatalog() CatalogConfig {
+	cfg := usCatalog()
+	cfg.EUStorageEndpoint = "s3.eu-west-3.amazonaws.com"
+	cfg.EUStorageBucket = "festivals-eu"
+	cfg.EUStorageRegion = "eu-west-3"
+	cfg.PostalEUURL = "https://postal.eu.example.com"
+	cfg.PostalEURegion = "eu-central-1"
+	cfg.TwilioHost = "api.dublin.ie1.twilio.com"
+	cfg.TwilioRegion = "ie1"
+	return cfg
+}
+
+func TestIsEURegion(t *testing.T) {
+	for region, want := range map[string]bool{
+		"eu-west-3":    true,
+		"EU-Central-1": true,
+		"ie1":          true,
+		"de1":          true,
+		"eu-west-2":    false, // London
+		"eu-central-2": false, // Zurich
+		"us-east-1":    false,
+		"":             false,
+	} {
+		assert.Equal(t, want, IsEURegion(region), region)
+	}
+}
+
+func TestService_UpdatePolicy(t *testing.T) {
+	festivalID := uuid.New()
+	userID := uuid.New()
+	ctx := context.Background()
+
+	t.Run("EU_ONLY is refused with providers outside the EU", func(t *testing.T) {
+		mockRepo := NewMockRepository()
+		service := NewService(mockRepo, NewCatalog(usCatalog()))
+		mockRepo.On("FestivalExists", mock.Anything, festivalID).Return(true, nil)
+		mockRepo.On("GetPolicy", mock.Anything, festivalID).Return(nil, nil).Once()
+
+		_, err := service.UpdatePolicy(ctx, festivalID, UpdatePolicyRequest{Mode: ModeEUOnly}, &userID)
+		require.ErrorIs(t, err, ErrProviderOutsideEU)
+
+		var policyErr *PolicyError
+		require.True(t, errors.As(err, &policyErr))
+		assert.Equal(t, []string{
+			"STORAGE provider minio is in us-east-1",
+			"EMAIL provider postal is in us-east-1",
+			"SMS provider twilio is in an undeclared region",
+		}, policyErr.Violations)
+
+		policy, err := service.GetPolicy(ctx, festivalID)
+		require.NoError(t, err)
+		assert.Equal(t, ModeStandard, policy.Mode)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "SavePolicy", mock.Anything, mock.Anything)
+	})
+
+	t.Run("EU_ONLY is saved with EU providers", func(t *testing.T) {
+		mockRepo := NewMockRepository()
+		service := NewService(mockRepo, NewCatalog(euCatalog()))
+		mockRepo.On("FestivalExists", mock.Anything, festivalID).Return(true, nil)
+		mockRepo.On("GetPolicy", mock.Anything, festivalID).Return(nil, nil).Once()
+		mockRepo.On("SavePolicy", mock.Anything, mock.MatchedBy(func(p *Policy) bool {
+			return p.FestivalID == festivalID && p.Mode == ModeEUOnly && p.Notes == "Client contract"
+		})).Return(nil).Once()
+
+		policy, err := service.UpdatePolicy(ctx, festivalID, UpdatePolicyRequest{Mode: ModeEUOnly, Notes: "Client contract"}, &userID)
+		require.NoError(t, err)
+		assert.Equal(t, ModeEUOnly, policy.Mode)
+		assert.Equal(t, &userID, policy.UpdatedBy)
+		assert.False(t, policy.CreatedAt.IsZero())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid mode and unknown festival", func(t *testing.T) {
+		mockRepo := NewMockRepository()
+		service := NewService(mockRepo, NewCatalog(euCatalog()))
+		unknownID := uuid.New()
+		mockRepo.On("FestivalExists", mock.Anything, unknownID).Return(false, nil).Once()
+
+		_, err := service.UpdatePolicy(ctx, festivalID, UpdatePolicyRequest{Mode: "US_ONLY"}, nil)
+		assert.ErrorIs(t, err, ErrInvalidMode)
+
+		_, err = service.UpdatePolicy(ctx, unknownID, UpdatePolicyRequest{Mode: ModeStandard}, nil)
+		assert.ErrorIs(t, err, ErrFestivalNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_Route(t *testing.T) {
+	pinned, standard := uuid.New(), uuid.New()
+	ctx := context.Background()
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, NewCatalog(euCatalog()))
+	mockRepo.On("GetPolicy", mock.Anything, pinned).Return(&Policy{FestivalID: pinned, Mode: ModeEUOnly}, nil)
+	mockRepo.On("GetPolicy", mock.Anything, standard).Return(nil, nil)
+
+	provider, err := service.Route(ctx, pinned, KindStorage)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderMinioEU, provider.ID)
+	assert.Equal(t, "festivals-eu", provider.Bucket)
+
+	provider, err = service.Route(ctx, pinned, KindEmail)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderPostalEU, provider.ID)
+
+	provider, err = service.Route(ctx, pinned, KindSMS)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTwilio, provider.ID)
+
+	// Festivals without a policy keep the default providers
+	provider, err = service.Route(ctx, standard, KindStorage)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderMinio, provider.ID)
+}
+
+func TestService_Audit(t *testing.T) {
+	festivalID := uuid.New()
+	ctx := context.Background()
+	mockRepo := NewMockRepository()
+	mockRepo.On("FestivalExists", mock.Anything, festivalID).Return(true, nil)
+	mockRepo.On("GetPolicy", mock.Anything, festivalID).Return(&Policy{FestivalID: festivalID, Mode: ModeEUOnly}, nil)
+
+	report, err := NewService(mockRepo, NewCatalog(euCatalog())).Audit(ctx, festivalID)
+	require.NoError(t, err)
+	assert.True(t, report.Compliant)
+	require.Len(t, report.Flows, 3)
+	for _, flow := range report.Flows {
+		assert.True(t, flow.InEU, flow.Data)
+	}
+
+	// Twilio moved back to the US after the policy was set
+	cfg := euCatalog()
+	cfg.TwilioRegion = ""
+	report, err = NewService(mockRepo, NewCatalog(cfg)).Audit(ctx, festivalID)
+	require.NoError(t, err)
+	assert.False(t, report.Compliant)
+	assert.Equal(t, []string{"SMS: SMS provider twilio is in an undeclared region"}, report.Violations)
+
+	sms := report.Flows[2]
+	assert.Equal(t, DataSMS, sms.Data)
+	assert.Equal(t, ProviderTwilio, sms.Provider)
+	assert.False(t, sms.Compliant)
+	assert.True(t, report.Flows[0].Compliant)
+}
@@ -0,0 +1,570 @@
+package restock
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped restock rules and turnaround
+// analytics, which should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	rules := r.Group("/restock-rules")
+	{
+		rules.GET("", h.ListRules)
+		rules.PUT("/:productId", h.SetRule)
+		rules.DELETE("/:productId", h.DeleteRule)
+	}
+
+	r.GET("/restock-analytics/turnaround", h.Turnaround)
+}
+
+// RegisterStaffRoutes registers the restock requests handled by the stand staff, the
+// warehouse and the couriers, which should be restricted to staff
+func (h *Handler) RegisterStaffRoutes(r *gin.RouterGroup) {
+	r.GET("/restock-queue", h.Queue)
+
+	requests := r.Group("/restock-requests")
+	{
+		requests.GET("", h.List)
+		requests.POST("", h.Create)
+		requests.GET("/:requestId", h.Get)
+		requests.GET("/:requestId/movements", h.ListMovements)
+		requests.POST("/:requestId/pick", h.StartPicking)
+		requests.POST("/:requestId/dispatch", h.Dispatch)
+		requests.POST("/:requestId/deliver", h.Deliver)
+		requests.POST("/:requestId/cancel", h.Cancel)
+	}
+}
+
+// Create opens a restock request
+// @Summary Request restock
+// @Description Ask the central warehouse to deliver products with limited stock to a stand
+// @Tags restock
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateRequest true "Products and quantities"
+// @Success 201 {object} response.Response{data=Request} "Restock requested"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand or product not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	request, err := h.service.Create(c.Request.Context(), festivalID, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, request)
+}
+
+// List lists the restock requests of the festival
+// @Summary List restock requests
+// @Description List the restock requests of the stands, latest first
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(REQUESTED, PICKING, IN_TRANSIT, DELIVERED, CANCELLED)
+// @Param standId query string false "Only the requests of this stand" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Request,meta=response.Meta} "Restock requests"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, filter, ok := listParams(c)
+	if !ok {
+		return
+	}
+
+	requests, total, err := h.service.List(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, requests, &response.Meta{
+		Total:   int(total),
+		Page:    filter.Offset/filter.Limit + 1,
+		PerPage: filter.Limit,
+	})
+}
+
+// Queue lists the picking queue of the warehouse
+// @Summary Warehouse picking queue
+// @Description Restock requests waiting for or being picked at the warehouse, oldest first
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Request} "Picking queue"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-queue [get]
+func (h *Handler) Queue(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.service.Queue(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, requests)
+}
+
+// Get returns a restock request
+// @Summary Get restock request
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param requestId path string true "Restock request ID" format(uuid)
+// @Success 200 {object} response.Response{data=Request} "Restock request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock request not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests/{requestId} [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, requestID, ok := pathParams(c, "requestId", "restock request")
+	if !ok {
+		return
+	}
+
+	request, err := h.service.Get(c.Request.Context(), festivalID, requestID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, request)
+}
+
+// ListMovements lists the stock movements of a delivered request
+// @Summary List restock stock movements
+// @Description Stock added to the products of the stand when the request was received
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param requestId path string true "Restock request ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Movement} "Stock movements"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock request not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests/{requestId}/movements [get]
+func (h *Handler) ListMovements(c *gin.Context) {
+	festivalID, requestID, ok := pathParams(c, "requestId", "restock request")
+	if !ok {
+		return
+	}
+
+	movements, err := h.service.ListMovements(c.Request.Context(), festivalID, requestID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, movements)
+}
+
+// StartPicking takes a request from the picking queue
+// @Summary Start picking restock request
+// @Description Take a request from the warehouse queue; only one picker can take it
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param requestId path string true "Restock request ID" format(uuid)
+// @Success 200 {object} response.Response{data=Request} "Picking"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock request not found"
+// @Failure 409 {object} response.ErrorResponse "Request no longer in the queue"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests/{requestId}/pick [post]
+func (h *Handler) StartPicking(c *gin.Context) {
+	festivalID, requestID, ok := pathParams(c, "requestId", "restock request")
+	if !ok {
+		return
+	}
+
+	request, err := h.service.StartPicking(c.Request.Context(), festivalID, requestID, currentUser(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, request)
+}
+
+// Dispatch hands a picked request to the courier
+// @Summary Dispatch restock request
+// @Description Hand a picked request to the courier with the quantities picked and optionally their unit cost; products left out are sent in full
+// @Tags restock
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param requestId path string true "Restock request ID" format(uuid)
+// @Param request body QuantitiesRequest false "Quantities picked"
+// @Success 200 {object} response.Response{data=Request} "In transit"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock request not found"
+// @Failure 409 {object} response.ErrorResponse "Request not being picked"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests/{requestId}/dispatch [post]
+func (h *Handler) Dispatch(c *gin.Context) {
+	festivalID, requestID, ok := pathParams(c, "requestId", "restock request")
+	if !ok {
+		return
+	}
+
+	req, ok := bindQuantities(c)
+	if !ok {
+		return
+	}
+
+	request, err := h.service.Dispatch(c.Request.Context(), festivalID, requestID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, request)
+}
+
+// Deliver confirms the reception of a request by the stand
+// @Summary Confirm restock delivery
+// @Description Confirm the quantities received by the stand, which are added to the stock of the products; products left out are received as picked. Unit costs update the cost price of the products to the weighted average.
+// @Tags restock
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param requestId path string true "Restock request ID" format(uuid)
+// @Param request body QuantitiesRequest false "Quantities received"
+// @Success 200 {object} response.Response{data=Request} "Delivered"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock request not found"
+// @Failure 409 {object} response.ErrorResponse "Request not in transit"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests/{requestId}/deliver [post]
+func (h *Handler) Deliver(c *gin.Context) {
+	festivalID, requestID, ok := pathParams(c, "requestId", "restock request")
+	if !ok {
+		return
+	}
+
+	req, ok := bindQuantities(c)
+	if !ok {
+		return
+	}
+
+	request, err := h.service.Deliver(c.Request.Context(), festivalID, requestID, currentUser(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, request)
+}
+
+// Cancel cancels a restock request not yet dispatched
+// @Summary Cancel restock request
+// @Tags restock
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param requestId path string true "Restock request ID" format(uuid)
+// @Param request body CancelRequest false "Reason"
+// @Success 200 {object} response.Response{data=Request} "Cancelled"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock request not found"
+// @Failure 409 {object} response.ErrorResponse "Request already dispatched"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-requests/{requestId}/cancel [post]
+func (h *Handler) Cancel(c *gin.Context) {
+	festivalID, requestID, ok := pathParams(c, "requestId", "restock request")
+	if !ok {
+		return
+	}
+
+	var req CancelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	request, err := h.service.Cancel(c.Request.Context(), festivalID, requestID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, request)
+}
+
+// ListRules lists the restock rules of the festival
+// @Summary List restock rules
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the rules of this stand" format(uuid)
+// @Success 200 {object} response.Response{data=[]Rule} "Restock rules"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-rules [get]
+func (h *Handler) ListRules(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return
+	}
+
+	rules, err := h.service.ListRules(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rules)
+}
+
+// SetRule sets the restock rule of a product
+// @Summary Set restock rule
+// @Description Request the quantity from the warehouse whenever the stock of the product falls to the threshold
+// @Tags restock
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param productId path string true "Product ID" format(uuid)
+// @Param request body SetRuleRequest true "Threshold and quantity"
+// @Success 200 {object} response.Response{data=Rule} "Restock rule"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-rules/{productId} [put]
+func (h *Handler) SetRule(c *gin.Context) {
+	festivalID, productID, ok := pathParams(c, "productId", "product")
+	if !ok {
+		return
+	}
+
+	var req SetRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	rule, err := h.service.SetRule(c.Request.Context(), festivalID, productID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rule)
+}
+
+// DeleteRule deletes the restock rule of a product
+// @Summary Delete restock rule
+// @Tags restock
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param productId path string true "Product ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock rule not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-rules/{productId} [delete]
+func (h *Handler) DeleteRule(c *gin.Context) {
+	festivalID, productID, ok := pathParams(c, "productId", "product")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRule(c.Request.Context(), festivalID, productID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Turnaround returns the turnaround analytics of the restock requests
+// @Summary Restock turnaround
+// @Description Average time of the restock requests in the warehouse queue, picking and transit, with the 90th percentile from request to delivery, in seconds, in total and per stand
+// @Tags restock
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param from query string false "Start (RFC 3339), 7 days before to by default"
+// @Param to query string false "End (RFC 3339), now by default"
+// @Param standId query string false "Only the requests of this stand" format(uuid)
+// @Success 200 {object} response.Response{data=Turnaround} "Turnaround"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-analytics/turnaround [get]
+func (h *Handler) Turnaround(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var query TurnaroundQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+	if query.StandID, ok = optionalUUID(c, "standId"); !ok {
+		return
+	}
+
+	turnaround, err := h.service.Turnaround(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, turnaround)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func pathParams(c *gin.Context, param, name string) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid "+name+" ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, id, true
+}
+
+func listParams(c *gin.Context) (uuid.UUID, ListFilter, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, ListFilter{}, false
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return uuid.Nil, ListFilter{}, false
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	return festivalID, ListFilter{
+		Status:  c.Query("status"),
+		StandID: standID,
+		Offset:  (page - 1) * perPage,
+		Limit:   perPage,
+	}, true
+}
+
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid "+name, nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+// bindQuantities binds the optional quantities of a dispatch or delivery
+func bindQuantities(c *gin.Context) (QuantitiesRequest, bool) {
+	var req QuantitiesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+			return req, false
+		}
+	}
+	return req, true
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRequestNotFound):
+		response.NotFound(c, "Restock request not found")
+	case errors.Is(err, ErrRuleNotFound):
+		response.NotFound(c, "Restock rule not found")
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	case errors.Is(err, ErrProductNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrEmptyRequest), errors.Is(err, ErrDuplicateProduct),
+		errors.Is(err, ErrInvalidQuantity), errors.Is(err, ErrInvalidUnitCost), errors.Is(err, ErrUnlimitedStock):
+		response.BadRequest(c, "INVALID_ITEMS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRuleSetting):
+		response.BadRequest(c, "INVALID_RULE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRange):
+		response.BadRequest(c, "INVALID_RANGE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidStatus):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidTransition):
+		response.Conflict(c, "INVALID_TRANSITION", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
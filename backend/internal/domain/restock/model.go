@@ -0,0 +1,274 @@
+package restock
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TypeScanLowStock is the worker task opening restock requests for the products at or
+// below the threshold of their rule
+const TypeScanLowStock = "restock:scan_low_stock"
+
+// LowStockCooldown is how long a cancelled request keeps the low-stock scan from
+// requesting its products again
+const LowStockCooldown = time.Hour
+
+// DefaultTurnaroundPeriod is the period of the turnaround analytics without a range
+const DefaultTurnaroundPeriod = 7 * 24 * time.Hour
+
+// Restock errors
+var (
+	ErrRequestNotFound    = errors.New("restock request not found")
+	ErrRuleNotFound       = errors.New("restock rule not found")
+	ErrStandNotFound      = errors.New("stand not found")
+	ErrProductNotFound    = errors.New("product not found at the stand")
+	ErrUnlimitedStock     = errors.New("product has unlimited stock")
+	ErrEmptyRequest       = errors.New("a restock request needs at least one product")
+	ErrDuplicateProduct   = errors.New("a product is listed twice")
+	ErrInvalidQuantity    = errors.New("quantity must be positive when requested, and not above the quantity requested or picked")
+	ErrInvalidUnitCost    = errors.New("unit cost must not be negative")
+	ErrInvalidTransition  = errors.New("restock request cannot move to this status")
+	ErrInvalidStatus      = errors.New("unknown status")
+	ErrInvalidRange       = errors.New("from must be before to")
+	ErrInvalidRuleSetting = errors.New("threshold must not be negative and quantity must be positive")
+)
+
+// Source is what opened a restock request
+type Source string
+
+const (
+	SourceManual   Source = "MANUAL"    // Requested by the stand staff
+	SourceLowStock Source = "LOW_STOCK" // Stock at or below the threshold of its rule
+	SourceSensor   Source = "SENSOR"    // Keg sensor nearly empty
+)
+
+// Status is the state of a restock request
+type Status string
+
+const (
+	StatusRequested Status = "REQUESTED"  // Waiting in the warehouse queue
+	StatusPicking   Status = "PICKING"    // Being picked at the warehouse
+	StatusInTransit Status = "IN_TRANSIT" // With the courier
+	StatusDelivered Status = "DELIVERED"  // Received by the stand, stock updated
+	StatusCancelled Status = "CANCELLED"
+)
+
+// IsValid checks if the status is valid
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusRequested, StatusPicking, StatusInTransit, StatusDelivered, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsOpen reports whether the request still awaits delivery
+func (s Status) IsOpen() bool {
+	return s == StatusRequested || s == StatusPicking || s == StatusInTransit
+}
+
+// Item is a product of a restock request with the quantities along the way
+type Item struct {
+	ProductID        uuid.UUID `json:"productId"`
+	ProductName      string    `json:"productName"`
+	Quantity         int       `json:"quantity"`                   // Requested
+	PickedQuantity   *int      `json:"pickedQuantity,omitempty"`   // Sent by the warehouse
+	ReceivedQuantity *int      `json:"receivedQuantity,omitempty"` // Confirmed by the stand
+	UnitCost         *int64    `json:"unitCost,omitempty"`         // Cost price of the delivered units in cents
+}
+
+// Items is the jsonb list of products of a restock request
+type Items []Item
+
+// Scan implements the sql.Scanner interface for Items
+func (i *Items) Scan(value interface{}) error {
+	if value == nil {
+		*i = make(Items, 0)
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal Items: %v", value)
+	}
+
+	return json.Unmarshal(bytes, i)
+}
+
+// Value implements the driver.Valuer interface for Items
+func (i Items) Value() (driver.Value, error) {
+	if i == nil {
+		return "[]", nil
+	}
+	return json.Marshal(i)
+}
+
+// Request asks the central warehouse to deliver products to a stand. It goes through
+// the picking queue of the warehouse, is carried by a courier and confirmed by the stand.
+type Request struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID   uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID      uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	StandName    string     `json:"standName" gorm:"not null"`
+	Source       Source     `json:"source" gorm:"not null"`
+	Status       Status     `json:"status" gorm:"not null;default:'REQUESTED'"`
+	Items        Items      `json:"items" gorm:"type:jsonb;not null"`
+	Note         string     `json:"note,omitempty"`
+	RequestedBy  *uuid.UUID `json:"requestedBy,omitempty" gorm:"type:uuid"` // Nil when opened automatically
+	PickedBy     *uuid.UUID `json:"pickedBy,omitempty" gorm:"type:uuid"`
+	ReceivedBy   *uuid.UUID `json:"receivedBy,omitempty" gorm:"type:uuid"`
+	CancelReason string     `json:"cancelReason,omitempty"`
+	RequestedAt  time.Time  `json:"requestedAt"`
+	PickingAt    *time.Time `json:"pickingAt,omitempty"`
+	DispatchedAt *time.Time `json:"dispatchedAt,omitempty"`
+	DeliveredAt  *time.Time `json:"deliveredAt,omitempty"`
+	CancelledAt  *time.Time `json:"cancelledAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func (Request) TableName() string {
+	return "restock_requests"
+}
+
+// Rule opens a restock request when the stock of a product falls to its threshold
+type Rule struct {
+	ProductID  uuid.UUID `json:"productId" gorm:"type:uuid;primary_key"`
+	FestivalID uuid.UUID `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    uuid.UUID `json:"standId" gorm:"type:uuid;not null"`
+	Threshold  int       `json:"threshold" gorm:"not null"` // Stock at or below which a request is opened
+	Quantity   int       `json:"quantity" gorm:"not null"`  // Quantity requested
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func (Rule) TableName() string {
+	return "restock_rules"
+}
+
+// Movement is a change of the stock of a product, recorded when a delivery is received
+type Movement struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null"`
+	StandID     uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	ProductID   uuid.UUID  `json:"productId" gorm:"type:uuid;not null"`
+	RequestID   *uuid.UUID `json:"requestId,omitempty" gorm:"type:uuid"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	StockBefore int        `json:"stockBefore"`
+	StockAfter  int        `json:"stockAfter"`
+	UnitCost    *int64     `json:"unitCost,omitempty"` // Cost price of the units received, in cents
+	CreatedBy   *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func (Movement) TableName() string {
+	return "product_stock_movements"
+}
+
+// ProductInfo is what restock requests need of a product
+type ProductInfo struct {
+	ID      uuid.UUID
+	StandID uuid.UUID
+	Name    string
+	Stock   *int
+}
+
+// StandInfo is what restock requests need of a stand
+type StandInfo struct {
+	ID         uuid.UUID
+	FestivalID uuid.UUID
+	Name       string
+}
+
+// LowStock is a product at or below the threshold of its rule
+type LowStock struct {
+	FestivalID  uuid.UUID
+	StandID     uuid.UUID
+	StandName   string
+	ProductID   uuid.UUID
+	ProductName string
+	Stock       int
+	Quantity    int
+}
+
+// CreateRequest represents the request to ask the warehouse for products
+type CreateRequest struct {
+	StandID uuid.UUID   `json:"standId" binding:"required"`
+	Items   []ItemInput `json:"items" binding:"required,dive"`
+	Note    string      `json:"note" binding:"max=500"`
+}
+
+// ItemInput is a quantity of a product
+type ItemInput struct {
+	ProductID uuid.UUID `json:"productId" binding:"required"`
+	Quantity  int       `json:"quantity"`
+	UnitCost  *int64    `json:"unitCost,omitempty"` // Cost price per unit, on dispatch or delivery only
+}
+
+// QuantitiesRequest sets the picked or received quantities of a restock request, from
+// 0 to the quantity of the previous step. Products left out keep that quantity. A unit
+// cost, when known, updates the cost price of the product once the delivery is received.
+type QuantitiesRequest struct {
+	Items []ItemInput `json:"items" binding:"omitempty,dive"`
+}
+
+// CancelRequest represents the request to cancel a restock request
+type CancelRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// SetRuleRequest represents the request to set the restock rule of a product
+type SetRuleRequest struct {
+	Threshold int `json:"threshold"`
+	Quantity  int `json:"quantity" binding:"required"`
+}
+
+// ListFilter filters the listed restock requests
+type ListFilter struct {
+	Status  string
+	StandID *uuid.UUID
+	Offset  int
+	Limit   int
+}
+
+// TurnaroundQuery selects the restock requests of the turnaround analytics
+type TurnaroundQuery struct {
+	From    time.Time  `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      time.Time  `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	StandID *uuid.UUID `form:"-"`
+}
+
+// StepTimes are the durations of the steps of the restock requests, in seconds. A
+// step is nil when no request went through it.
+type StepTimes struct {
+	AvgQueue     *float64 `json:"avgQueue"`     // Requested until picking started
+	AvgPicking   *float64 `json:"avgPicking"`   // Picking until dispatched
+	AvgTransit   *float64 `json:"avgTransit"`   // Dispatched until received
+	AvgTotal     *float64 `json:"avgTotal"`     // Requested until received
+	P90Total     *float64 `json:"p90Total"`     // 90th percentile of the total
+	Requests     int64    `json:"requests"`     // Requested in the period
+	Delivered    int64    `json:"delivered"`    // Of which delivered
+	Cancelled    int64    `json:"cancelled"`    // Of which cancelled
+	Pending      int64    `json:"pending"`      // Of which still open
+	ShortShipped int64    `json:"shortShipped"` // Delivered with less than requested
+}
+
+// StandTurnaround is the turnaround of the restock requests of a stand
+type StandTurnaround struct {
+	StandID   uuid.UUID `json:"standId"`
+	StandName string    `json:"standName"`
+	StepTimes
+}
+
+// Turnaround is the turnaround of the restock requests of a festival over a period
+type Turnaround struct {
+	From   time.Time         `json:"from"`
+	To     time.Time         `json:"to"`
+	Total  StepTimes         `json:"total"`
+	Stands []StandTurnaround `json:"stands"`
+}
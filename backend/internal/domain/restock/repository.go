@@ -0,0 +1,400 @@
+package restock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, request *Request) error
+	Get(ctx context.Context, festivalID, id uuid.UUID) (*Request, error)
+	List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Request, int64, error)
+	// ListQueue lists the requests waiting for or being picked, oldest first
+	ListQueue(ctx context.Context, festivalID uuid.UUID) ([]Request, error)
+	// Transition saves a request moving from the status it had when read; it returns
+	// ErrInvalidTransition when another user moved it first
+	Transition(ctx context.Context, request *Request, from Status) error
+	// Deliver saves a delivered request, adds the received quantities to the stock of
+	// the products and records the movements, in one transaction
+	Deliver(ctx context.Context, request *Request) ([]Movement, error)
+	ListMovements(ctx context.Context, requestID uuid.UUID) ([]Movement, error)
+	HasOpenRequest(ctx context.Context, standID, productID uuid.UUID) (bool, error)
+
+	GetRule(ctx context.Context, festivalID, productID uuid.UUID) (*Rule, error)
+	SaveRule(ctx context.Context, rule *Rule) error
+	ListRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Rule, error)
+	DeleteRule(ctx context.Context, productID uuid.UUID) error
+	// ListLowStock lists the products of active festivals at or below the threshold of
+	// their rule, without an open request nor one cancelled since cancelledAfter
+	ListLowStock(ctx context.Context, cancelledAfter time.Time) ([]LowStock, error)
+
+	// Turnaround measures the steps of the requests of a festival requested in [from, to)
+	Turnaround(ctx context.Context, festivalID uuid.UUID, query TurnaroundQuery) (*StepTimes, []StandTurnaround, error)
+
+	GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error)
+	ListProducts(ctx context.Context, productIDs []uuid.UUID) ([]ProductInfo, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, request *Request) error {
+	if err := r.db.WithContext(ctx).Create(request).Error; err != nil {
+		return fmt.Errorf("failed to create restock request: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Request, error) {
+	var request Request
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get restock request: %w", err)
+	}
+	return &request, nil
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Request, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Request{}).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StandID != nil {
+		query = query.Where("stand_id = ?", *filter.StandID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count restock requests: %w", err)
+	}
+
+	var requests []Request
+	if err := query.Order("requested_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&requests).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list restock requests: %w", err)
+	}
+	return requests, total, nil
+}
+
+func (r *repository) ListQueue(ctx context.Context, festivalID uuid.UUID) ([]Request, error) {
+	var requests []Request
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND status IN ?", festivalID, []Status{StatusRequested, StatusPicking}).
+		Order("requested_at").
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list restock queue: %w", err)
+	}
+	return requests, nil
+}
+
+func (r *repository) Transition(ctx context.Context, request *Request, from Status) error {
+	return transition(r.db.WithContext(ctx), request, from)
+}
+
+func (r *repository) Deliver(ctx context.Context, request *Request) ([]Movement, error) {
+	var movements []Movement
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := transition(tx, request, StatusInTransit); err != nil {
+			return err
+		}
+
+		for _, item := range request.Items {
+			if item.ReceivedQuantity == nil || *item.ReceivedQuantity == 0 {
+				continue
+			}
+
+			// Products made unlimited or deleted since the request keep their stock. The
+			// cost price becomes the average of the stock left and the units received.
+			var stocks []int
+			err := tx.Raw(`
+				UPDATE public.products
+				SET stock = stock + ?,
+					cost_price = CASE
+						WHEN ?::bigint IS NULL THEN cost_price
+						WHEN cost_price IS NULL OR stock <= 0 THEN ?
+						ELSE ROUND((cost_price * stock + ?::bigint * ?)::numeric / (stock + ?))
+					END,
+					status = CASE WHEN status = 'OUT_OF_STOCK' THEN 'ACTIVE' ELSE status END,
+					updated_at = ?
+				WHERE id = ? AND stock IS NOT NULL
+				RETURNING stock
+			`, *item.ReceivedQuantity,
+				item.UnitCost, item.UnitCost, item.UnitCost, *item.ReceivedQuantity, *item.ReceivedQuantity,
+				request.UpdatedAt, item.ProductID).Scan(&stocks).Error
+			if err != nil {
+				return fmt.Errorf("failed to update product stock: %w", err)
+			}
+			if len(stocks) == 0 {
+				if item.UnitCost != nil {
+					err := tx.Exec(`
+						UPDATE public.products SET cost_price = ?, updated_at = ?
+						WHERE id = ? AND stock IS NULL
+					`, *item.UnitCost, request.UpdatedAt, item.ProductID).Error
+					if err != nil {
+						return fmt.Errorf("failed to update product cost price: %w", err)
+					}
+				}
+				continue
+			}
+
+			movement := Movement{
+				ID:          uuid.New(),
+				FestivalID:  request.FestivalID,
+				StandID:     request.StandID,
+				ProductID:   item.ProductID,
+				RequestID:   &request.ID,
+				Quantity:    *item.ReceivedQuantity,
+				StockBefore: stocks[0] - *item.ReceivedQuantity,
+				StockAfter:  stocks[0],
+				UnitCost:    item.UnitCost,
+				CreatedBy:   request.ReceivedBy,
+				CreatedAt:   request.UpdatedAt,
+			}
+			if err := tx.Create(&movement).Error; err != nil {
+				return fmt.Errorf("failed to record stock movement: %w", err)
+			}
+			movements = append(movements, movement)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (r *repository) ListMovements(ctx context.Context, requestID uuid.UUID) ([]Movement, error) {
+	var movements []Movement
+	if err := r.db.WithContext(ctx).Where("request_id = ?", requestID).Order("created_at").Find(&movements).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+	return movements, nil
+}
+
+func (r *repository) HasOpenRequest(ctx context.Context, standID, productID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&Request{}).
+		Where("stand_id = ? AND status IN ?", standID, []Status{StatusRequested, StatusPicking, StatusInTransit}).
+		Where("items @> jsonb_build_array(jsonb_build_object('productId', ?::text))", productID.String()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check open restock requests: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *repository) GetRule(ctx context.Context, festivalID, productID uuid.UUID) (*Rule, error) {
+	var rule Rule
+	err := r.db.WithContext(ctx).Where("product_id = ? AND festival_id = ?", productID, festivalID).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get restock rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func (r *repository) SaveRule(ctx context.Context, rule *Rule) error {
+	if err := r.db.WithContext(ctx).Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to save restock rule: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Rule, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ?", *standID)
+	}
+
+	var rules []Rule
+	if err := query.Order("stand_id, created_at").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list restock rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *repository) DeleteRule(ctx context.Context, productID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&Rule{}, "product_id = ?", productID).Error; err != nil {
+		return fmt.Errorf("failed to delete restock rule: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListLowStock(ctx context.Context, cancelledAfter time.Time) ([]LowStock, error) {
+	var rows []LowStock
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT ru.festival_id, ru.stand_id, s.name AS stand_name, p.id AS product_id,
+			p.name AS product_name, p.stock, ru.quantity
+		FROM public.restock_rules ru
+		INNER JOIN public.products p ON p.id = ru.product_id
+		INNER JOIN public.stands s ON s.id = ru.stand_id
+		INNER JOIN public.festivals f ON f.id = ru.festival_id
+		WHERE f.status = 'ACTIVE'
+			AND p.status IN ('ACTIVE', 'OUT_OF_STOCK')
+			AND p.stock IS NOT NULL
+			AND p.stock <= ru.threshold
+			AND NOT EXISTS (
+				SELECT 1 FROM public.restock_requests rq
+				WHERE rq.stand_id = ru.stand_id
+					AND (rq.status IN ('REQUESTED', 'PICKING', 'IN_TRANSIT')
+						OR (rq.status = 'CANCELLED' AND rq.cancelled_at > ?))
+					AND rq.items @> jsonb_build_array(jsonb_build_object('productId', p.id::text))
+			)
+		ORDER BY ru.stand_id, p.name
+	`, cancelledAfter).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low stock products: %w", err)
+	}
+	return rows, nil
+}
+
+// turnaroundRow is a row of the turnaround aggregates
+type turnaroundRow struct {
+	StandID      uuid.UUID `gorm:"column:stand_id"`
+	StandName    string    `gorm:"column:stand_name"`
+	Requests     int64     `gorm:"column:requests"`
+	Delivered    int64     `gorm:"column:delivered"`
+	Cancelled    int64     `gorm:"column:cancelled"`
+	Pending      int64     `gorm:"column:pending"`
+	ShortShipped int64     `gorm:"column:short_shipped"`
+	AvgQueue     *float64  `gorm:"column:avg_queue"`
+	AvgPicking   *float64  `gorm:"column:avg_picking"`
+	AvgTransit   *float64  `gorm:"column:avg_transit"`
+	AvgTotal     *float64  `gorm:"column:avg_total"`
+	P90Total     *float64  `gorm:"column:p90_total"`
+}
+
+func (row turnaroundRow) stepTimes() StepTimes {
+	return StepTimes{
+		AvgQueue:     row.AvgQueue,
+		AvgPicking:   row.AvgPicking,
+		AvgTransit:   row.AvgTransit,
+		AvgTotal:     row.AvgTotal,
+		P90Total:     row.P90Total,
+		Requests:     row.Requests,
+		Delivered:    row.Delivered,
+		Cancelled:    row.Cancelled,
+		Pending:      row.Pending,
+		ShortShipped: row.ShortShipped,
+	}
+}
+
+// turnaroundAggregates are the aggregates of the turnaround, over the requests r
+const turnaroundAggregates = `
+	COUNT(*) AS requests,
+	COUNT(*) FILTER (WHERE r.status = 'DELIVERED') AS delivered,
+	COUNT(*) FILTER (WHERE r.status = 'CANCELLED') AS cancelled,
+	COUNT(*) FILTER (WHERE r.status IN ('REQUESTED', 'PICKING', 'IN_TRANSIT')) AS pending,
+	COUNT(*) FILTER (WHERE r.status = 'DELIVERED' AND EXISTS (
+		SELECT 1 FROM jsonb_array_elements(r.items) i
+		WHERE COALESCE((i->>'receivedQuantity')::int, 0) < (i->>'quantity')::int
+	)) AS short_shipped,
+	AVG(EXTRACT(EPOCH FROM r.picking_at - r.requested_at)) AS avg_queue,
+	AVG(EXTRACT(EPOCH FROM r.dispatched_at - r.picking_at)) AS avg_picking,
+	AVG(EXTRACT(EPOCH FROM r.delivered_at - r.dispatched_at)) AS avg_transit,
+	AVG(EXTRACT(EPOCH FROM r.delivered_at - r.requested_at)) AS avg_total,
+	percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM r.delivered_at - r.requested_at)) AS p90_total
+`
+
+func (r *repository) Turnaround(ctx context.Context, festivalID uuid.UUID, query TurnaroundQuery) (*StepTimes, []StandTurnaround, error) {
+	where := "r.festival_id = ? AND r.requested_at >= ? AND r.requested_at < ?"
+	args := []interface{}{festivalID, query.From, query.To}
+	if query.StandID != nil {
+		where += " AND r.stand_id = ?"
+		args = append(args, *query.StandID)
+	}
+
+	var totals []turnaroundRow
+	err := r.db.WithContext(ctx).Raw(
+		"SELECT "+turnaroundAggregates+" FROM public.restock_requests r WHERE "+where,
+		args...,
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get restock turnaround: %w", err)
+	}
+
+	var rows []turnaroundRow
+	err = r.db.WithContext(ctx).Raw(
+		"SELECT r.stand_id, MAX(r.stand_name) AS stand_name, "+turnaroundAggregates+
+			" FROM public.restock_requests r WHERE "+where+
+			" GROUP BY r.stand_id ORDER BY avg_total DESC NULLS LAST",
+		args...,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get restock turnaround per stand: %w", err)
+	}
+
+	var total StepTimes
+	if len(totals) > 0 {
+		total = totals[0].stepTimes()
+	}
+	stands := make([]StandTurnaround, len(rows))
+	for i, row := range rows {
+		stands[i] = StandTurnaround{
+			StandID:   row.StandID,
+			StandName: row.StandName,
+			StepTimes: row.stepTimes(),
+		}
+	}
+	return &total, stands, nil
+}
+
+func (r *repository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	var infos []StandInfo
+	err := r.db.WithContext(ctx).
+		Table("public.stands").
+		Select("id, festival_id, name").
+		Where("id = ?", standID).
+		Limit(1).
+		Scan(&infos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	return &infos[0], nil
+}
+
+func (r *repository) ListProducts(ctx context.Context, productIDs []uuid.UUID) ([]ProductInfo, error) {
+	var products []ProductInfo
+	err := r.db.WithContext(ctx).
+		Table("public.products").
+		Select("id, stand_id, name, stock").
+		Where("id IN ?", productIDs).
+		Scan(&products).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	return products, nil
+}
+
+func transition(db *gorm.DB, request *Request, from Status) error {
+	result := db.Model(&Request{}).
+		Where("id = ? AND status = ?", request.ID, from).
+		Select("*").
+		Updates(request)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update restock request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTransition
+	}
+	return nil
+}
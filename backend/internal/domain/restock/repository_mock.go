@@ -0,0 +1,110 @@
+package restock
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) Create(ctx context.Context, request *Request) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Request, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Request), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Request, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Request), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) ListQueue(ctx context.Context, festivalID uuid.UUID) ([]Request, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Request), args.Error(1)
+}
+
+func (m *MockRepository) Transition(ctx context.Context, request *Request, from Status) error {
+	args := m.Called(ctx, request, from)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Deliver(ctx context.Context, request *Request) ([]Movement, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).([]Movement), args.Error(1)
+}
+
+func (m *MockRepository) ListMovements(ctx context.Context, requestID uuid.UUID) ([]Movement, error) {
+	args := m.Called(ctx, requestID)
+	return args.Get(0).([]Movement), args.Error(1)
+}
+
+func (m *MockRepository) HasOpenRequest(ctx context.Context, standID, productID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, standID, productID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetRule(ctx context.Context, festivalID, productID uuid.UUID) (*Rule, error) {
+	args := m.Called(ctx, festivalID, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Rule), args.Error(1)
+}
+
+func (m *MockRepository) SaveRule(ctx context.Context, rule *Rule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Rule, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]Rule), args.Error(1)
+}
+
+func (m *MockRepository) DeleteRule(ctx context.Context, productID uuid.UUID) error {
+	args := m.Called(ctx, productID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLowStock(ctx context.Context, cancelledAfter time.Time) ([]LowStock, error) {
+	args := m.Called(ctx, cancelledAfter)
+	return args.Get(0).([]LowStock), args.Error(1)
+}
+
+func (m *MockRepository) Turnaround(ctx context.Context, festivalID uuid.UUID, query TurnaroundQuery) (*StepTimes, []StandTurnaround, error) {
+	args := m.Called(ctx, festivalID, query)
+	return args.Get(0).(*StepTimes), args.Get(1).([]StandTurnaround), args.Error(2)
+}
+
+func (m *MockRepository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	args := m.Called(ctx, standID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandInfo), args.Error(1)
+}
+
+func (m *MockRepository) ListProducts(ctx context.Context, productIDs []uuid.UUID) ([]ProductInfo, error) {
+	args := m.Called(ctx, productIDs)
+	return args.Get(0).([]ProductInfo), args.Error(1)
+}
@@ -0,0 +1,500 @@
+package restock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/rs/zerolog/log"
+)
+
+// Alerter broadcasts organizer alerts; satisfied by activity.Service
+type Alerter interface {
+	BroadcastAlert(festivalID string, alert *realtime.Alert)
+}
+
+// Service runs the restock requests of the stands to the central warehouse: opened
+// manually, from low stock or from a keg sensor, picked at the warehouse, carried
+// by a courier and received by the stand, whose stock is updated on delivery
+type Service struct {
+	repo    Repository
+	alerter Alerter
+	now     func() time.Time
+}
+
+// NewService creates a new restock service
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// SetAlerter sets the service used to tell the warehouse about new requests
+func (s *Service) SetAlerter(alerter Alerter) {
+	s.alerter = alerter
+}
+
+// Create opens a restock request of the stand staff
+func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, requestedBy *uuid.UUID, req CreateRequest) (*Request, error) {
+	stand, err := s.getStand(ctx, festivalID, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Items) == 0 {
+		return nil, ErrEmptyRequest
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.Items))
+	seen := make(map[uuid.UUID]bool, len(req.Items))
+	for _, input := range req.Items {
+		if input.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if seen[input.ProductID] {
+			return nil, ErrDuplicateProduct
+		}
+		seen[input.ProductID] = true
+		ids = append(ids, input.ProductID)
+	}
+
+	products, err := s.repo.ListProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]ProductInfo, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	items := make(Items, 0, len(req.Items))
+	for _, input := range req.Items {
+		product, exists := byID[input.ProductID]
+		if !exists || product.StandID != stand.ID {
+			return nil, ErrProductNotFound
+		}
+		if product.Stock == nil {
+			return nil, ErrUnlimitedStock
+		}
+		items = append(items, Item{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Quantity:    input.Quantity,
+		})
+	}
+
+	return s.open(ctx, stand, SourceManual, items, req.Note, requestedBy)
+}
+
+// RequestKeg opens a restock request for the product of a keg nearly empty, unless
+// one is already open. The quantity is the one of the rule of the product, one
+// otherwise.
+func (s *Service) RequestKeg(ctx context.Context, festivalID, standID, productID uuid.UUID) error {
+	stand, err := s.getStand(ctx, festivalID, standID)
+	if err != nil {
+		return err
+	}
+
+	open, err := s.repo.HasOpenRequest(ctx, standID, productID)
+	if err != nil || open {
+		return err
+	}
+
+	products, err := s.repo.ListProducts(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return err
+	}
+	if len(products) == 0 || products[0].StandID != standID {
+		return ErrProductNotFound
+	}
+
+	quantity := 1
+	rule, err := s.repo.GetRule(ctx, festivalID, productID)
+	if err != nil {
+		return err
+	}
+	if rule != nil {
+		quantity = rule.Quantity
+	}
+
+	items := Items{{ProductID: productID, ProductName: products[0].Name, Quantity: quantity}}
+	_, err = s.open(ctx, stand, SourceSensor, items, "", nil)
+	return err
+}
+
+// HandleScanLowStock handles the periodic scan of the products at or below the
+// threshold of their rule
+func (s *Service) HandleScanLowStock(ctx context.Context, t *asynq.Task) error {
+	opened, err := s.ScanLowStock(ctx)
+	if err != nil {
+		return err
+	}
+	if opened > 0 {
+		log.Info().Int("requests", opened).Msg("Opened restock requests for low stock")
+	}
+	return nil
+}
+
+// ScanLowStock opens one restock request per stand for its products at or below the
+// threshold of their rule, and returns how many were opened. Products already on an
+// open request, or on one cancelled within the cooldown, are left out.
+func (s *Service) ScanLowStock(ctx context.Context) (int, error) {
+	lows, err := s.repo.ListLowStock(ctx, s.now().Add(-LowStockCooldown))
+	if err != nil {
+		return 0, err
+	}
+
+	opened := 0
+	for start := 0; start < len(lows); {
+		end := start
+		items := Items{}
+		for end < len(lows) && lows[end].StandID == lows[start].StandID {
+			items = append(items, Item{
+				ProductID:   lows[end].ProductID,
+				ProductName: lows[end].ProductName,
+				Quantity:    lows[end].Quantity,
+			})
+			end++
+		}
+
+		stand := &StandInfo{ID: lows[start].StandID, FestivalID: lows[start].FestivalID, Name: lows[start].StandName}
+		if _, err := s.open(ctx, stand, SourceLowStock, items, "", nil); err != nil {
+			return opened, err
+		}
+		opened++
+		start = end
+	}
+	return opened, nil
+}
+
+// List lists the restock requests of a festival, latest first
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Request, int64, error) {
+	if filter.Status != "" && !Status(filter.Status).IsValid() {
+		return nil, 0, ErrInvalidStatus
+	}
+	return s.repo.List(ctx, festivalID, filter)
+}
+
+// Queue lists the picking queue of the warehouse: the requests waiting for or being
+// picked, oldest first
+func (s *Service) Queue(ctx context.Context, festivalID uuid.UUID) ([]Request, error) {
+	return s.repo.ListQueue(ctx, festivalID)
+}
+
+// Get gets a restock request
+func (s *Service) Get(ctx context.Context, festivalID, id uuid.UUID) (*Request, error) {
+	request, err := s.repo.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, ErrRequestNotFound
+	}
+	return request, nil
+}
+
+// ListMovements lists the stock movements recorded by the delivery of a request
+func (s *Service) ListMovements(ctx context.Context, festivalID, id uuid.UUID) ([]Movement, error) {
+	if _, err := s.Get(ctx, festivalID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMovements(ctx, id)
+}
+
+// StartPicking takes a request from the queue of the warehouse
+func (s *Service) StartPicking(ctx context.Context, festivalID, id uuid.UUID, pickedBy *uuid.UUID) (*Request, error) {
+	request, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != StatusRequested {
+		return nil, ErrInvalidTransition
+	}
+
+	now := s.now()
+	request.Status = StatusPicking
+	request.PickedBy = pickedBy
+	request.PickingAt = &now
+	request.UpdatedAt = now
+	if err := s.repo.Transition(ctx, request, StatusRequested); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// Dispatch hands a picked request to the courier, with the quantities picked. The
+// warehouse may send less than requested but must send something.
+func (s *Service) Dispatch(ctx context.Context, festivalID, id uuid.UUID, req QuantitiesRequest) (*Request, error) {
+	request, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != StatusPicking {
+		return nil, ErrInvalidTransition
+	}
+
+	inputs, err := inputsByProduct(request.Items, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	sent := 0
+	for i := range request.Items {
+		item := &request.Items[i]
+		picked := item.Quantity
+		if input, exists := inputs[item.ProductID]; exists {
+			picked = input.Quantity
+			if input.UnitCost != nil {
+				item.UnitCost = input.UnitCost
+			}
+		}
+		if picked > item.Quantity {
+			return nil, ErrInvalidQuantity
+		}
+		item.PickedQuantity = &picked
+		sent += picked
+	}
+	if sent == 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	now := s.now()
+	request.Status = StatusInTransit
+	request.DispatchedAt = &now
+	request.UpdatedAt = now
+	if err := s.repo.Transition(ctx, request, StatusPicking); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// Deliver confirms the reception of a request by the stand, with the quantities
+// received, and adds them to the stock of the products. The products of the items with
+// a unit cost get the weighted average of their cost price and the delivery's.
+func (s *Service) Deliver(ctx context.Context, festivalID, id uuid.UUID, receivedBy *uuid.UUID, req QuantitiesRequest) (*Request, error) {
+	request, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != StatusInTransit {
+		return nil, ErrInvalidTransition
+	}
+
+	inputs, err := inputsByProduct(request.Items, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	for i := range request.Items {
+		item := &request.Items[i]
+		picked := item.Quantity
+		if item.PickedQuantity != nil {
+			picked = *item.PickedQuantity
+		}
+		received := picked
+		if input, exists := inputs[item.ProductID]; exists {
+			received = input.Quantity
+			if input.UnitCost != nil {
+				item.UnitCost = input.UnitCost
+			}
+		}
+		if received > picked {
+			return nil, ErrInvalidQuantity
+		}
+		item.ReceivedQuantity = &received
+	}
+
+	now := s.now()
+	request.Status = StatusDelivered
+	request.ReceivedBy = receivedBy
+	request.DeliveredAt = &now
+	request.UpdatedAt = now
+	if _, err := s.repo.Deliver(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// Cancel cancels a request not yet dispatched. A request with the courier can only
+// be delivered, with the quantities actually received.
+func (s *Service) Cancel(ctx context.Context, festivalID, id uuid.UUID, req CancelRequest) (*Request, error) {
+	request, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != StatusRequested && request.Status != StatusPicking {
+		return nil, ErrInvalidTransition
+	}
+
+	from := request.Status
+	now := s.now()
+	request.Status = StatusCancelled
+	request.CancelReason = req.Reason
+	request.CancelledAt = &now
+	request.UpdatedAt = now
+	if err := s.repo.Transition(ctx, request, from); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// SetRule sets the threshold at which a product is restocked and the quantity requested
+func (s *Service) SetRule(ctx context.Context, festivalID, productID uuid.UUID, req SetRuleRequest) (*Rule, error) {
+	if req.Threshold < 0 || req.Quantity <= 0 {
+		return nil, ErrInvalidRuleSetting
+	}
+
+	products, err := s.repo.ListProducts(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrProductNotFound
+	}
+	if _, err := s.getStand(ctx, festivalID, products[0].StandID); err != nil {
+		if errors.Is(err, ErrStandNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if products[0].Stock == nil {
+		return nil, ErrUnlimitedStock
+	}
+
+	now := s.now()
+	rule, err := s.repo.GetRule(ctx, festivalID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		rule = &Rule{
+			ProductID:  productID,
+			FestivalID: festivalID,
+			StandID:    products[0].StandID,
+			CreatedAt:  now,
+		}
+	}
+	rule.Threshold = req.Threshold
+	rule.Quantity = req.Quantity
+	rule.UpdatedAt = now
+
+	if err := s.repo.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// ListRules lists the restock rules of a festival, optionally of one stand
+func (s *Service) ListRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Rule, error) {
+	return s.repo.ListRules(ctx, festivalID, standID)
+}
+
+// DeleteRule stops restocking a product automatically
+func (s *Service) DeleteRule(ctx context.Context, festivalID, productID uuid.UUID) error {
+	rule, err := s.repo.GetRule(ctx, festivalID, productID)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrRuleNotFound
+	}
+	return s.repo.DeleteRule(ctx, productID)
+}
+
+// Turnaround measures how long the restock requests of a festival take at each step,
+// over the last 7 days by default
+func (s *Service) Turnaround(ctx context.Context, festivalID uuid.UUID, query TurnaroundQuery) (*Turnaround, error) {
+	if query.To.IsZero() {
+		query.To = s.now()
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-DefaultTurnaroundPeriod)
+	}
+	if !query.From.Before(query.To) {
+		return nil, ErrInvalidRange
+	}
+
+	total, stands, err := s.repo.Turnaround(ctx, festivalID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Turnaround{
+		From:   query.From,
+		To:     query.To,
+		Total:  *total,
+		Stands: stands,
+	}, nil
+}
+
+// open creates a restock request and tells the warehouse
+func (s *Service) open(ctx context.Context, stand *StandInfo, source Source, items Items, note string, requestedBy *uuid.UUID) (*Request, error) {
+	now := s.now()
+	request := &Request{
+		ID:          uuid.New(),
+		FestivalID:  stand.FestivalID,
+		StandID:     stand.ID,
+		StandName:   stand.Name,
+		Source:      source,
+		Status:      StatusRequested,
+		Items:       items,
+		Note:        note,
+		RequestedBy: requestedBy,
+		RequestedAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if s.alerter != nil {
+		s.alerter.BroadcastAlert(stand.FestivalID.String(), &realtime.Alert{
+			ID:        request.ID.String(),
+			Type:      "info",
+			Title:     "Restock requested: " + stand.Name,
+			Message:   fmt.Sprintf("%d product(s) to pick at the warehouse", len(items)),
+			ActionURL: fmt.Sprintf("/festivals/%s/restock-requests/%s", stand.FestivalID, request.ID),
+		})
+	}
+	return request, nil
+}
+
+func (s *Service) getStand(ctx context.Context, festivalID, standID uuid.UUID) (*StandInfo, error) {
+	stand, err := s.repo.GetStandInfo(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil || stand.FestivalID != festivalID {
+		return nil, ErrStandNotFound
+	}
+	return stand, nil
+}
+
+// inputsByProduct indexes the quantities and unit costs of the products of a request
+func inputsByProduct(items Items, inputs []ItemInput) (map[uuid.UUID]ItemInput, error) {
+	listed := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		listed[item.ProductID] = true
+	}
+
+	indexed := make(map[uuid.UUID]ItemInput, len(inputs))
+	for _, input := range inputs {
+		if !listed[input.ProductID] {
+			return nil, ErrProductNotFound
+		}
+		if _, exists := indexed[input.ProductID]; exists {
+			return nil, ErrDuplicateProduct
+		}
+		if input.Quantity < 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if input.UnitCost != nil && *input.UnitCost < 0 {
+			return nil, ErrInvalidUnitCost
+		}
+		indexed[input.ProductID] = input
+	}
+	return indexed, nil
+}
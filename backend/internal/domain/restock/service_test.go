@@ -0,0 +1,212 @@
+package restock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(repo Repository) *Service {
+	service := NewService(repo)
+	service.now = func() time.Time { return time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC) }
+	return service
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func testStand(festivalID uuid.UUID, name string) *StandInfo {
+	return &StandInfo{ID: uuid.New(), FestivalID: festivalID, Name: name}
+}
+
+func testProduct(standID uuid.UUID, name string, stock *int) ProductInfo {
+	return ProductInfo{ID: uuid.New(), StandID: standID, Name: name, Stock: stock}
+}
+
+// expectCreate records the requests opened by the service
+func expectCreate(mockRepo *MockRepository) *[]*Request {
+	created := &[]*Request{}
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*restock.Request")).
+		Run(func(args mock.Arguments) { *created = append(*created, args.Get(1).(*Request)) }).
+		Return(nil)
+	return created
+}
+
+func TestService_RequestLifecycle(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	stand := testStand(festivalID, "Main bar")
+	lager := testProduct(stand.ID, "Lager", intPtr(2))
+	cider := testProduct(stand.ID, "Cider", intPtr(0))
+	ctx := context.Background()
+
+	// The request is served back as stored, so each step sees the previous ones
+	stored := &Request{}
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	mockRepo.On("ListProducts", mock.Anything, []uuid.UUID{lager.ID, cider.ID}).Return([]ProductInfo{lager, cider}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*restock.Request")).
+		Run(func(args mock.Arguments) {
+			*stored = *args.Get(1).(*Request)
+			mockRepo.On("Get", mock.Anything, festivalID, stored.ID).Return(stored, nil)
+		}).
+		Return(nil).Once()
+
+	request, err := service.Create(ctx, festivalID, nil, CreateRequest{
+		StandID: stand.ID,
+		Items:   []ItemInput{{ProductID: lager.ID, Quantity: 24}, {ProductID: cider.ID, Quantity: 12}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusRequested, request.Status)
+	assert.Equal(t, SourceManual, request.Source)
+
+	picker := uuid.New()
+	mockRepo.On("Transition", mock.Anything, stored, StatusRequested).Return(nil).Once()
+	_, err = service.StartPicking(ctx, festivalID, request.ID, &picker)
+	require.NoError(t, err)
+	assert.Equal(t, &picker, stored.PickedBy)
+	_, err = service.StartPicking(ctx, festivalID, request.ID, &picker)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	negative := int64(-1)
+	_, err = service.Dispatch(ctx, festivalID, request.ID, QuantitiesRequest{
+		Items: []ItemInput{{ProductID: cider.ID, Quantity: 6, UnitCost: &negative}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidUnitCost)
+
+	// The warehouse is short of cider
+	cost := int64(80)
+	mockRepo.On("Transition", mock.Anything, stored, StatusPicking).Return(nil).Once()
+	request, err = service.Dispatch(ctx, festivalID, request.ID, QuantitiesRequest{
+		Items: []ItemInput{{ProductID: cider.ID, Quantity: 6, UnitCost: &cost}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusInTransit, request.Status)
+	assert.Equal(t, 24, *request.Items[0].PickedQuantity)
+	assert.Equal(t, 6, *request.Items[1].PickedQuantity)
+	assert.Nil(t, request.Items[0].UnitCost)
+	assert.Equal(t, &cost, request.Items[1].UnitCost)
+
+	_, err = service.Cancel(ctx, festivalID, request.ID, CancelRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	// One lager case broke on the way
+	_, err = service.Deliver(ctx, festivalID, request.ID, nil, QuantitiesRequest{
+		Items: []ItemInput{{ProductID: lager.ID, Quantity: 30}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	mockRepo.On("Deliver", mock.Anything, mock.MatchedBy(func(r *Request) bool {
+		return r.ID == stored.ID && *r.Items[0].ReceivedQuantity == 23 && *r.Items[1].ReceivedQuantity == 6
+	})).Return([]Movement{
+		{ProductID: lager.ID, RequestID: &stored.ID, Quantity: 23, StockBefore: 2, StockAfter: 25},
+		{ProductID: cider.ID, RequestID: &stored.ID, Quantity: 6, StockBefore: 0, StockAfter: 6},
+	}, nil).Once()
+	request, err = service.Deliver(ctx, festivalID, request.ID, nil, QuantitiesRequest{
+		Items: []ItemInput{{ProductID: lager.ID, Quantity: 23}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusDelivered, request.Status)
+	assert.NotNil(t, request.DeliveredAt)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "Transition", 2)
+}
+
+func TestService_CreateValidatesProducts(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	stand := testStand(festivalID, "Main bar")
+	other := testStand(festivalID, "Food court")
+	water := testProduct(stand.ID, "Water", nil)
+	foreign := testProduct(other.ID, "Lager", intPtr(10))
+	lager := testProduct(stand.ID, "Lager", intPtr(10))
+	ctx := context.Background()
+
+	mockRepo.On("GetStandInfo", mock.Anything, stand.ID).Return(stand, nil)
+	mockRepo.On("ListProducts", mock.Anything, []uuid.UUID{water.ID}).Return([]ProductInfo{water}, nil).Once()
+	mockRepo.On("ListProducts", mock.Anything, []uuid.UUID{foreign.ID}).Return([]ProductInfo{foreign}, nil).Once()
+
+	_, err := service.Create(ctx, festivalID, nil, CreateRequest{StandID: stand.ID, Items: []ItemInput{{ProductID: water.ID, Quantity: 6}}})
+	assert.ErrorIs(t, err, ErrUnlimitedStock)
+
+	_, err = service.Create(ctx, festivalID, nil, CreateRequest{StandID: stand.ID, Items: []ItemInput{{ProductID: foreign.ID, Quantity: 6}}})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	_, err = service.Create(ctx, festivalID, nil, CreateRequest{StandID: stand.ID, Items: []ItemInput{{ProductID: lager.ID, Quantity: 0}}})
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	_, err = service.Create(ctx, festivalID, nil, CreateRequest{
+		StandID: stand.ID,
+		Items:   []ItemInput{{ProductID: lager.ID, Quantity: 6}, {ProductID: lager.ID, Quantity: 6}},
+	})
+	assert.ErrorIs(t, err, ErrDuplicateProduct)
+
+	_, err = service.Create(ctx, uuid.New(), nil, CreateRequest{StandID: stand.ID, Items: []ItemInput{{ProductID: lager.ID, Quantity: 6}}})
+	assert.ErrorIs(t, err, ErrStandNotFound)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestService_AutomaticRequests(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	bar := testStand(festivalID, "Main bar")
+	food := testStand(festivalID, "Food court")
+	keg := testProduct(bar.ID, "Lager keg", intPtr(1))
+	ctx := context.Background()
+	created := expectCreate(mockRepo)
+
+	mockRepo.On("ListLowStock", mock.Anything, service.now().Add(-LowStockCooldown)).Return([]LowStock{
+		{FestivalID: festivalID, StandID: bar.ID, StandName: "Main bar", ProductID: uuid.New(), ProductName: "Cider", Quantity: 24},
+		{FestivalID: festivalID, StandID: bar.ID, StandName: "Main bar", ProductID: uuid.New(), ProductName: "Lemonade", Quantity: 12},
+		{FestivalID: festivalID, StandID: food.ID, StandName: "Food court", ProductID: uuid.New(), ProductName: "Fries", Quantity: 50},
+	}, nil)
+	opened, err := service.ScanLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, opened)
+
+	perStand := make(map[uuid.UUID]int)
+	for _, request := range *created {
+		assert.Equal(t, SourceLowStock, request.Source)
+		perStand[request.StandID] += len(request.Items)
+	}
+	assert.Equal(t, 2, perStand[bar.ID])
+	assert.Equal(t, 1, perStand[food.ID])
+
+	// A keg sensor requests the quantity of the rule, once while the request is open
+	rule := &Rule{}
+	mockRepo.On("GetStandInfo", mock.Anything, bar.ID).Return(bar, nil)
+	mockRepo.On("ListProducts", mock.Anything, []uuid.UUID{keg.ID}).Return([]ProductInfo{keg}, nil)
+	mockRepo.On("GetRule", mock.Anything, festivalID, keg.ID).Return(nil, nil).Once()
+	mockRepo.On("SaveRule", mock.Anything, mock.AnythingOfType("*restock.Rule")).
+		Run(func(args mock.Arguments) { *rule = *args.Get(1).(*Rule) }).
+		Return(nil).Once()
+	mockRepo.On("GetRule", mock.Anything, festivalID, keg.ID).Return(rule, nil)
+	mockRepo.On("HasOpenRequest", mock.Anything, bar.ID, keg.ID).Return(false, nil).Once()
+	mockRepo.On("HasOpenRequest", mock.Anything, bar.ID, keg.ID).Return(true, nil).Once()
+
+	_, err = service.SetRule(ctx, festivalID, keg.ID, SetRuleRequest{Threshold: 1, Quantity: 4})
+	require.NoError(t, err)
+	require.NoError(t, service.RequestKeg(ctx, festivalID, bar.ID, keg.ID))
+	require.NoError(t, service.RequestKeg(ctx, festivalID, bar.ID, keg.ID))
+
+	var kegRequests []*Request
+	for _, request := range *created {
+		if request.Source == SourceSensor {
+			kegRequests = append(kegRequests, request)
+		}
+	}
+	require.Len(t, kegRequests, 1)
+	assert.Equal(t, 4, kegRequests[0].Items[0].Quantity)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,235 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *PolicyService
+}
+
+func NewHandler(service *PolicyService) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped retention policies, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	retention := r.Group("/retention")
+	{
+		retention.GET("/policies", h.ListPolicies)
+		retention.PUT("/policies/:class", h.UpdatePolicy)
+		retention.DELETE("/policies/:class", h.ResetPolicy)
+		retention.GET("/executions", h.ListExecutions)
+		retention.GET("/report", h.ComplianceReport)
+	}
+}
+
+// ListPolicies lists the retention of each data class of the festival
+// @Summary List retention policies
+// @Description List the retention applied to each data class of the festival, its default or the one the festival set, and whether its data is purged or anonymized
+// @Tags retention
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]EffectivePolicy} "Retention policies"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/retention/policies [get]
+func (h *Handler) ListPolicies(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	policies, err := h.service.ListPolicies(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, policies)
+}
+
+// UpdatePolicy sets the retention of a data class of the festival
+// @Summary Set a retention policy
+// @Description Set the retention in days of a data class of the festival. It cannot go below the legal minimum of the class. The next daily sweep applies it.
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param class path string true "TRANSACTIONS, ANALYTICS_EVENTS, LOCATION_PINGS or AUDIT_LOGS"
+// @Param request body UpdatePolicyRequest true "Retention in days"
+// @Success 200 {object} response.Response{data=EffectivePolicy} "Retention policy"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Unknown data class"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/retention/policies/{class} [put]
+func (h *Handler) UpdatePolicy(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(c.Request.Context(), festivalID, classParam(c), req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, policy)
+}
+
+// ResetPolicy puts a data class of the festival back on its default retention
+// @Summary Reset a retention policy
+// @Description Put a data class of the festival back on its default retention
+// @Tags retention
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param class path string true "TRANSACTIONS, ANALYTICS_EVENTS, LOCATION_PINGS or AUDIT_LOGS"
+// @Success 204 "Policy reset"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Unknown data class"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/retention/policies/{class} [delete]
+func (h *Handler) ResetPolicy(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.ResetPolicy(c.Request.Context(), festivalID, classParam(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListExecutions lists the enforcements of the retention policies of the festival
+// @Summary List retention executions
+// @Description List the daily purges and anonymizations of the data of the festival, latest first, with the cutoff applied and the rows affected
+// @Tags retention
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param class query string false "Only this data class"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Execution,meta=response.Meta} "Retention executions"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/retention/executions [get]
+func (h *Handler) ListExecutions(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := pagination(c)
+	class := DataClass(strings.ToUpper(c.Query("class")))
+	executions, total, err := h.service.ListExecutions(c.Request.Context(), festivalID, class, (page-1)*perPage, perPage)
+	if err != nil {
+		if errors.Is(err, ErrUnknownClass) {
+			response.BadRequest(c, "INVALID_CLASS", err.Error(), nil)
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, executions, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// ComplianceReport tells whether the festival stores data past its retention
+// @Summary Get retention compliance report
+// @Description For each data class, the retention, the oldest record not purged or anonymized yet and the last enforcement. A class is compliant when it holds no data older than its retention, allowing the daily sweep two days.
+// @Tags retention
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=ComplianceReport} "Compliance report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/retention/report [get]
+func (h *Handler) ComplianceReport(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.ComplianceReport(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownClass):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrRetentionTooLow):
+		response.BadRequest(c, "RETENTION_TOO_LOW", err.Error(), nil)
+	case errors.Is(err, ErrRetentionTooHigh):
+		response.BadRequest(c, "RETENTION_TOO_HIGH", fmt.Sprintf("Retention is at most %d days", MaxRetentionDays), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func classParam(c *gin.Context) DataClass {
+	return DataClass(strings.ToUpper(c.Param("class")))
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
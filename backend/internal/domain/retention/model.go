@@ -0,0 +1,25 @@
+package retention
+
+// TypeAnonymizeIPs is the worker task truncating the client IPs stored past the
+// retention period
+const TypeAnonymizeIPs = "retention:anonymize_ips"
+
+// batchSize is the number of rows anonymized per statement, so the first sweep of a
+// large table does not lock it at once
+const batchSize = 1000
+
+// Target is a column of client IPs and the column dating its rows
+type Target struct {
+	Table    string
+	IPColumn string
+	AtColumn string
+}
+
+// Targets are the tables storing client IPs
+var Targets = []Target{
+	{Table: "audit_logs", IPColumn: "ip", AtColumn: "timestamp"},
+	{Table: "rbac_audit_logs", IPColumn: "ip_address", AtColumn: "created_at"},
+	{Table: "impersonation_sessions", IPColumn: "ip_address", AtColumn: "created_at"},
+	{Table: "impersonation_audit_logs", IPColumn: "ip_address", AtColumn: "created_at"},
+	{Table: "user_role_changes", IPColumn: "ip_address", AtColumn: "created_at"},
+}
@@ -0,0 +1,207 @@
+package retention
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Retention policy errors
+var (
+	ErrUnknownClass     = errors.New("unknown data class")
+	ErrRetentionTooLow  = errors.New("retention is below the minimum of the data class")
+	ErrRetentionTooHigh = errors.New("retention is above the maximum of the data class")
+)
+
+// TypeEnforcePolicies is the worker task purging or anonymizing the data of every
+// festival past the retention of its class
+const TypeEnforcePolicies = "retention:enforce_policies"
+
+// MaxRetentionDays is the longest retention a festival may set, about 30 years
+const MaxRetentionDays = 11000
+
+// complianceGrace is how late the daily sweep may be before data past its retention
+// makes a festival non-compliant
+const complianceGrace = 48 * time.Hour
+
+// DataClass is a kind of stored data with its own retention
+type DataClass string
+
+const (
+	ClassTransactions    DataClass = "TRANSACTIONS"
+	ClassAnalyticsEvents DataClass = "ANALYTICS_EVENTS"
+	ClassLocationPings   DataClass = "LOCATION_PINGS"
+	ClassAuditLogs       DataClass = "AUDIT_LOGS"
+)
+
+// Action is what happens to data past its retention
+type Action string
+
+const (
+	ActionPurge     Action = "PURGE"     // Rows are deleted
+	ActionAnonymize Action = "ANONYMIZE" // Personal columns are cleared, the rows are kept
+)
+
+// ClassDefinition is a data class with its default retention and the rows it covers
+type ClassDefinition struct {
+	Class       DataClass `json:"class"`
+	Description string    `json:"description"`
+	Action      Action    `json:"action"`
+	DefaultDays int       `json:"defaultDays"`
+	MinDays     int       `json:"minDays"` // Legal minimum, e.g. bookkeeping
+	target      classTarget
+}
+
+// classTarget tells the repository where the rows of a class are. Identifiers and SQL
+// fragments are constants, never input.
+type classTarget struct {
+	Table         string
+	AtColumn      string
+	FestivalScope string // Condition on the festival, with one placeholder
+	Unscoped      string // Condition on the rows of no festival; empty when there are none
+	Pending       string // Condition on the rows left to anonymize
+	Anonymize     string // SET clause of the anonymization
+}
+
+// Classes are the data classes, in the order they are reported
+var Classes = []ClassDefinition{
+	{
+		Class:       ClassTransactions,
+		Description: "Wallet transactions; kept for the bookkeeping retention period",
+		Action:      ActionPurge,
+		DefaultDays: 3650,
+		MinDays:     3650,
+		target: classTarget{
+			Table:         "transactions",
+			AtColumn:      "created_at",
+			FestivalScope: "wallet_id IN (SELECT id FROM public.wallets WHERE festival_id = ?)",
+		},
+	},
+	{
+		Class:       ClassAnalyticsEvents,
+		Description: "App analytics events",
+		Action:      ActionPurge,
+		DefaultDays: 730,
+		MinDays:     1,
+		target: classTarget{
+			Table:         "analytics_events",
+			AtColumn:      "timestamp",
+			FestivalScope: "festival_id = ?",
+			Unscoped:      "festival_id IS NULL",
+		},
+	},
+	{
+		Class:       ClassLocationPings,
+		Description: "Coordinates sent with the app analytics events; the events are kept",
+		Action:      ActionAnonymize,
+		DefaultDays: 30,
+		MinDays:     1,
+		target: classTarget{
+			Table:         "analytics_events",
+			AtColumn:      "timestamp",
+			FestivalScope: "festival_id = ?",
+			Unscoped:      "festival_id IS NULL",
+			Pending:       "(latitude IS NOT NULL OR longitude IS NOT NULL)",
+			Anonymize:     "latitude = NULL, longitude = NULL",
+		},
+	},
+	{
+		Class:       ClassAuditLogs,
+		Description: "Audit log entries",
+		Action:      ActionPurge,
+		DefaultDays: 90,
+		MinDays:     1,
+		target: classTarget{
+			Table:         "audit_logs",
+			AtColumn:      "timestamp",
+			FestivalScope: "festival_id = ?",
+			Unscoped:      "festival_id IS NULL",
+		},
+	},
+}
+
+// classDefinition returns the definition of a class
+func classDefinition(class DataClass) (ClassDefinition, bool) {
+	for _, def := range Classes {
+		if def.Class == class {
+			return def, true
+		}
+	}
+	return ClassDefinition{}, false
+}
+
+// Policy is the retention a festival set for a class, replacing its default
+type Policy struct {
+	FestivalID    uuid.UUID  `json:"festivalId" gorm:"type:uuid;primaryKey"`
+	Class         DataClass  `json:"class" gorm:"primaryKey"`
+	RetentionDays int        `json:"retentionDays" gorm:"not null"`
+	UpdatedBy     *uuid.UUID `json:"updatedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (Policy) TableName() string {
+	return "retention_policies"
+}
+
+// EffectivePolicy is the retention applied to a class of a festival
+type EffectivePolicy struct {
+	ClassDefinition
+	RetentionDays int        `json:"retentionDays"`
+	IsDefault     bool       `json:"isDefault"`
+	UpdatedBy     *uuid.UUID `json:"updatedBy,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ExecutionStatus is the outcome of the enforcement of a policy
+type ExecutionStatus string
+
+const (
+	ExecutionSucceeded ExecutionStatus = "SUCCEEDED"
+	ExecutionFailed    ExecutionStatus = "FAILED"
+)
+
+// Execution records an enforcement of the policy of a class, kept as proof of
+// compliance. FestivalID is nil for the data of no festival.
+type Execution struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID    *uuid.UUID      `json:"festivalId,omitempty" gorm:"type:uuid;index"`
+	Class         DataClass       `json:"class" gorm:"not null"`
+	Action        Action          `json:"action" gorm:"not null"`
+	RetentionDays int             `json:"retentionDays" gorm:"not null"`
+	Cutoff        time.Time       `json:"cutoff" gorm:"not null"` // Data before it was purged or anonymized
+	Affected      int64           `json:"affected" gorm:"not null"`
+	Status        ExecutionStatus `json:"status" gorm:"not null"`
+	Error         string          `json:"error,omitempty"`
+	StartedAt     time.Time       `json:"startedAt"`
+	FinishedAt    time.Time       `json:"finishedAt"`
+}
+
+func (Execution) TableName() string {
+	return "retention_executions"
+}
+
+// ComplianceItem tells whether a class of a festival holds data past its retention
+type ComplianceItem struct {
+	Class         DataClass  `json:"class"`
+	Action        Action     `json:"action"`
+	RetentionDays int        `json:"retentionDays"`
+	Cutoff        time.Time  `json:"cutoff"`
+	OldestRecord  *time.Time `json:"oldestRecord,omitempty"` // Oldest row not purged or anonymized yet
+	LastExecution *Execution `json:"lastExecution,omitempty"`
+	Compliant     bool       `json:"compliant"`
+}
+
+// ComplianceReport is the retention compliance of a festival
+type ComplianceReport struct {
+	FestivalID  uuid.UUID        `json:"festivalId"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Compliant   bool             `json:"compliant"`
+	Classes     []ComplianceItem `json:"classes"`
+}
+
+// UpdatePolicyRequest sets the retention of a class
+type UpdatePolicyRequest struct {
+	RetentionDays int `json:"retentionDays" binding:"required,min=1"`
+}
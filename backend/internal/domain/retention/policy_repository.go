@@ -0,0 +1,165 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PolicyRepository interface {
+	ListPolicies(ctx context.Context, festivalID uuid.UUID) ([]Policy, error)
+	SavePolicy(ctx context.Context, policy *Policy) error
+	DeletePolicy(ctx context.Context, festivalID uuid.UUID, class DataClass) error
+	// ListFestivalIDs returns every festival, whose data the sweep enforces
+	ListFestivalIDs(ctx context.Context) ([]uuid.UUID, error)
+
+	// Enforce purges or anonymizes at most limit rows of the class stored before a time,
+	// of a festival or of no festival when festivalID is nil, and returns how many
+	Enforce(ctx context.Context, def ClassDefinition, festivalID *uuid.UUID, before time.Time, limit int) (int64, error)
+	// OldestRecord returns the date of the oldest row of the class left to purge or
+	// anonymize, nil when there is none
+	OldestRecord(ctx context.Context, def ClassDefinition, festivalID uuid.UUID) (*time.Time, error)
+
+	CreateExecution(ctx context.Context, execution *Execution) error
+	ListExecutions(ctx context.Context, festivalID uuid.UUID, class DataClass, offset, limit int) ([]Execution, int64, error)
+	// LatestExecutions returns the last execution of each class of a festival
+	LatestExecutions(ctx context.Context, festivalID uuid.UUID) (map[DataClass]Execution, error)
+}
+
+type policyRepository struct {
+	db *gorm.DB
+}
+
+func NewPolicyRepository(db *gorm.DB) PolicyRepository {
+	return &policyRepository{db: db}
+}
+
+func (r *policyRepository) ListPolicies(ctx context.Context, festivalID uuid.UUID) ([]Policy, error) {
+	var policies []Policy
+	if err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	return policies, nil
+}
+
+func (r *policyRepository) SavePolicy(ctx context.Context, policy *Policy) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "festival_id"}, {Name: "class"}},
+		DoUpdates: clause.AssignmentColumns([]string{"retention_days", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+	if err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+	return nil
+}
+
+func (r *policyRepository) DeletePolicy(ctx context.Context, festivalID uuid.UUID, class DataClass) error {
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND class = ?", festivalID, class).Delete(&Policy{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+	return nil
+}
+
+func (r *policyRepository) ListFestivalIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := r.db.WithContext(ctx).Table("public.festivals").Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to list festivals: %w", err)
+	}
+	return ids, nil
+}
+
+// where returns the conditions selecting the rows of a class of a festival, or of no
+// festival when festivalID is nil
+func where(def ClassDefinition, festivalID *uuid.UUID) (string, []interface{}) {
+	t := def.target
+	condition, args := t.Unscoped, []interface{}{}
+	if festivalID != nil {
+		condition, args = t.FestivalScope, []interface{}{*festivalID}
+	}
+	if def.Action == ActionAnonymize {
+		condition += " AND " + t.Pending
+	}
+	return condition, args
+}
+
+func (r *policyRepository) Enforce(ctx context.Context, def ClassDefinition, festivalID *uuid.UUID, before time.Time, limit int) (int64, error) {
+	t := def.target
+	condition, args := where(def, festivalID)
+	if condition == "" {
+		return 0, nil
+	}
+
+	// Identifiers and conditions come from Classes, never from input
+	selection := fmt.Sprintf(`SELECT id FROM public.%s WHERE %s AND %s < ? LIMIT ?`, t.Table, condition, t.AtColumn)
+	var query string
+	if def.Action == ActionAnonymize {
+		query = fmt.Sprintf(`UPDATE public.%s SET %s WHERE id IN (%s)`, t.Table, t.Anonymize, selection)
+	} else {
+		query = fmt.Sprintf(`DELETE FROM public.%s WHERE id IN (%s)`, t.Table, selection)
+	}
+
+	result := r.db.WithContext(ctx).Exec(query, append(args, before, limit)...)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to enforce retention of %s: %w", def.Class, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *policyRepository) OldestRecord(ctx context.Context, def ClassDefinition, festivalID uuid.UUID) (*time.Time, error) {
+	t := def.target
+	condition, args := where(def, &festivalID)
+
+	var oldest *time.Time
+	query := fmt.Sprintf(`SELECT MIN(%s) FROM public.%s WHERE %s`, t.AtColumn, t.Table, condition)
+	if err := r.db.WithContext(ctx).Raw(query, args...).Row().Scan(&oldest); err != nil {
+		return nil, fmt.Errorf("failed to get oldest record of %s: %w", def.Class, err)
+	}
+	return oldest, nil
+}
+
+func (r *policyRepository) CreateExecution(ctx context.Context, execution *Execution) error {
+	if err := r.db.WithContext(ctx).Create(execution).Error; err != nil {
+		return fmt.Errorf("failed to create retention execution: %w", err)
+	}
+	return nil
+}
+
+func (r *policyRepository) ListExecutions(ctx context.Context, festivalID uuid.UUID, class DataClass, offset, limit int) ([]Execution, int64, error) {
+	var executions []Execution
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&Execution{}).Where("festival_id = ?", festivalID)
+	if class != "" {
+		db = db.Where("class = ?", class)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count retention executions: %w", err)
+	}
+	if err := db.Order("started_at DESC").Offset(offset).Limit(limit).Find(&executions).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list retention executions: %w", err)
+	}
+	return executions, total, nil
+}
+
+func (r *policyRepository) LatestExecutions(ctx context.Context, festivalID uuid.UUID) (map[DataClass]Execution, error) {
+	var executions []Execution
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (class) *
+		FROM public.retention_executions
+		WHERE festival_id = ?
+		ORDER BY class, started_at DESC`, festivalID).Scan(&executions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest retention executions: %w", err)
+	}
+
+	latest := make(map[DataClass]Execution, len(executions))
+	for _, execution := range executions {
+		latest[execution.Class] = execution
+	}
+	return latest, nil
+}
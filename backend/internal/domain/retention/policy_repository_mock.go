@@ -0,0 +1,69 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPolicyRepository is a mock implementation of the PolicyRepository interface
+type MockPolicyRepository struct {
+	mock.Mock
+}
+
+// Ensure MockPolicyRepository implements PolicyRepository interface
+var _ PolicyRepository = (*MockPolicyRepository)(nil)
+
+func NewMockPolicyRepository() *MockPolicyRepository {
+	return &MockPolicyRepository{}
+}
+
+func (m *MockPolicyRepository) ListPolicies(ctx context.Context, festivalID uuid.UUID) ([]Policy, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Policy), args.Error(1)
+}
+
+func (m *MockPolicyRepository) SavePolicy(ctx context.Context, policy *Policy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockPolicyRepository) DeletePolicy(ctx context.Context, festivalID uuid.UUID, class DataClass) error {
+	args := m.Called(ctx, festivalID, class)
+	return args.Error(0)
+}
+
+func (m *MockPolicyRepository) ListFestivalIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockPolicyRepository) Enforce(ctx context.Context, def ClassDefinition, festivalID *uuid.UUID, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, def, festivalID, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPolicyRepository) OldestRecord(ctx context.Context, def ClassDefinition, festivalID uuid.UUID) (*time.Time, error) {
+	args := m.Called(ctx, def, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockPolicyRepository) CreateExecution(ctx context.Context, execution *Execution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+func (m *MockPolicyRepository) ListExecutions(ctx context.Context, festivalID uuid.UUID, class DataClass, offset, limit int) ([]Execution, int64, error) {
+	args := m.Called(ctx, festivalID, class, offset, limit)
+	return args.Get(0).([]Execution), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPolicyRepository) LatestExecutions(ctx context.Context, festivalID uuid.UUID) (map[DataClass]Execution, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).(map[DataClass]Execution), args.Error(1)
+}
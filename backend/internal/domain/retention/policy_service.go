@@ -0,0 +1,245 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// PolicyService applies the retention of each data class of the festivals, their
+// default or the one a festival set, and keeps a report of each enforcement
+type PolicyService struct {
+	repo PolicyRepository
+	now  func() time.Time
+}
+
+func NewPolicyService(repo PolicyRepository) *PolicyService {
+	return &PolicyService{repo: repo, now: time.Now}
+}
+
+// ListPolicies returns the retention applied to each data class of a festival
+func (s *PolicyService) ListPolicies(ctx context.Context, festivalID uuid.UUID) ([]EffectivePolicy, error) {
+	policies, err := s.repo.ListPolicies(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	return effectivePolicies(policies), nil
+}
+
+// UpdatePolicy sets the retention of a data class of a festival, within the bounds of
+// the class
+func (s *PolicyService) UpdatePolicy(ctx context.Context, festivalID uuid.UUID, class DataClass, req UpdatePolicyRequest, updatedBy *uuid.UUID) (*EffectivePolicy, error) {
+	def, ok := classDefinition(class)
+	if !ok {
+		return nil, ErrUnknownClass
+	}
+	if req.RetentionDays < def.MinDays {
+		return nil, ErrRetentionTooLow
+	}
+	if req.RetentionDays > MaxRetentionDays {
+		return nil, ErrRetentionTooHigh
+	}
+
+	now := s.now()
+	policy := &Policy{
+		FestivalID:    festivalID,
+		Class:         class,
+		RetentionDays: req.RetentionDays,
+		UpdatedBy:     updatedBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("festival_id", festivalID.String()).
+		Str("class", string(class)).
+		Int("retention_days", req.RetentionDays).
+		Msg("Retention policy updated")
+
+	effective := effectivePolicies([]Policy{*policy})
+	for i := range effective {
+		if effective[i].Class == class {
+			return &effective[i], nil
+		}
+	}
+	return nil, ErrUnknownClass
+}
+
+// ResetPolicy puts a data class of a festival back on its default retention
+func (s *PolicyService) ResetPolicy(ctx context.Context, festivalID uuid.UUID, class DataClass) error {
+	if _, ok := classDefinition(class); !ok {
+		return ErrUnknownClass
+	}
+	return s.repo.DeletePolicy(ctx, festivalID, class)
+}
+
+// ListExecutions returns the enforcements of the policies of a festival, latest first,
+// of one class when class is set
+func (s *PolicyService) ListExecutions(ctx context.Context, festivalID uuid.UUID, class DataClass, offset, limit int) ([]Execution, int64, error) {
+	if class != "" {
+		if _, ok := classDefinition(class); !ok {
+			return nil, 0, ErrUnknownClass
+		}
+	}
+	return s.repo.ListExecutions(ctx, festivalID, class, offset, limit)
+}
+
+// ComplianceReport tells, for each data class of a festival, whether data older than
+// its retention is still stored, with the last enforcement as evidence. The daily sweep
+// may be up to two days late before a class is reported non-compliant.
+func (s *PolicyService) ComplianceReport(ctx context.Context, festivalID uuid.UUID) (*ComplianceReport, error) {
+	policies, err := s.ListPolicies(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.repo.LatestExecutions(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	report := &ComplianceReport{FestivalID: festivalID, GeneratedAt: now, Compliant: true}
+	for _, policy := range policies {
+		oldest, err := s.repo.OldestRecord(ctx, policy.ClassDefinition, festivalID)
+		if err != nil {
+			return nil, err
+		}
+
+		item := ComplianceItem{
+			Class:         policy.Class,
+			Action:        policy.Action,
+			RetentionDays: policy.RetentionDays,
+			Cutoff:        cutoff(now, policy.RetentionDays),
+			OldestRecord:  oldest,
+		}
+		if execution, ok := latest[policy.Class]; ok {
+			item.LastExecution = &execution
+		}
+		item.Compliant = oldest == nil || !oldest.Before(item.Cutoff.Add(-complianceGrace))
+		if !item.Compliant {
+			report.Compliant = false
+		}
+		report.Classes = append(report.Classes, item)
+	}
+	return report, nil
+}
+
+// HandleEnforcePolicies handles the daily sweep of the data past its retention
+func (s *PolicyService) HandleEnforcePolicies(ctx context.Context, t *asynq.Task) error {
+	affected, err := s.EnforcePolicies(ctx)
+	if affected > 0 {
+		log.Info().Int64("affected", affected).Msg("Enforced data retention policies")
+	}
+	return err
+}
+
+// EnforcePolicies purges or anonymizes the data of every festival past the retention
+// of its class, then the data of no festival past the default retention, and records an
+// execution for each. A failing class does not stop the others; the errors are returned
+// together once every class was tried.
+func (s *PolicyService) EnforcePolicies(ctx context.Context) (int64, error) {
+	festivalIDs, err := s.repo.ListFestivalIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	var errs []error
+	for _, festivalID := range festivalIDs {
+		policies, err := s.ListPolicies(ctx, festivalID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, policy := range policies {
+			affected, err := s.enforce(ctx, policy.ClassDefinition, &festivalID, policy.RetentionDays)
+			total += affected
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, def := range Classes {
+		if def.target.Unscoped == "" {
+			continue
+		}
+		affected, err := s.enforce(ctx, def, nil, def.DefaultDays)
+		total += affected
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// enforce applies the retention of a class in batches and records the execution
+func (s *PolicyService) enforce(ctx context.Context, def ClassDefinition, festivalID *uuid.UUID, retentionDays int) (int64, error) {
+	execution := &Execution{
+		FestivalID:    festivalID,
+		Class:         def.Class,
+		Action:        def.Action,
+		RetentionDays: retentionDays,
+		Cutoff:        cutoff(s.now(), retentionDays),
+		Status:        ExecutionSucceeded,
+		StartedAt:     s.now(),
+	}
+
+	var enforceErr error
+	for {
+		affected, err := s.repo.Enforce(ctx, def, festivalID, execution.Cutoff, batchSize)
+		if err != nil {
+			enforceErr = err
+			break
+		}
+		execution.Affected += affected
+		if affected < batchSize {
+			break
+		}
+	}
+	if enforceErr != nil {
+		execution.Status = ExecutionFailed
+		execution.Error = enforceErr.Error()
+		log.Error().Err(enforceErr).Str("class", string(def.Class)).Msg("Failed to enforce retention policy")
+	}
+	execution.FinishedAt = s.now()
+
+	if err := s.repo.CreateExecution(ctx, execution); err != nil {
+		return execution.Affected, err
+	}
+	return execution.Affected, enforceErr
+}
+
+// effectivePolicies applies the policies a festival set over the class defaults
+func effectivePolicies(policies []Policy) []EffectivePolicy {
+	set := make(map[DataClass]Policy, len(policies))
+	for _, policy := range policies {
+		set[policy.Class] = policy
+	}
+
+	effective := make([]EffectivePolicy, 0, len(Classes))
+	for _, def := range Classes {
+		policy := EffectivePolicy{ClassDefinition: def, RetentionDays: def.DefaultDays, IsDefault: true}
+		if custom, ok := set[def.Class]; ok {
+			updatedAt := custom.UpdatedAt
+			policy.RetentionDays = custom.RetentionDays
+			policy.IsDefault = false
+			policy.UpdatedBy = custom.UpdatedBy
+			policy.UpdatedAt = &updatedAt
+		}
+		effective = append(effective, policy)
+	}
+	return effective
+}
+
+// cutoff is the time before which data kept for a number of days is past its retention
+func cutoff(now time.Time, retentionDays int) time.Time {
+	return now.AddDate(0, 0, -retentionDays)
+}
@@ -0,0 +1,148 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPolicyService(repo PolicyRepository, now time.Time) *PolicyService {
+	service := NewPolicyService(repo)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+// ofClass matches the definition of a data class
+func ofClass(class DataClass) interface{} {
+	return mock.MatchedBy(func(def ClassDefinition) bool { return def.Class == class })
+}
+
+func TestPolicyService_UpdatePolicyBounds(t *testing.T) {
+	mockRepo := NewMockPolicyRepository()
+	service := newTestPolicyService(mockRepo, time.Date(2026, 7, 18, 3, 0, 0, 0, time.UTC))
+	festivalID := uuid.New()
+	ctx := context.Background()
+	mockRepo.On("ListPolicies", mock.Anything, festivalID).Return([]Policy(nil), nil).Twice()
+
+	policies, err := service.ListPolicies(ctx, festivalID)
+	require.NoError(t, err)
+	require.Len(t, policies, len(Classes))
+	assert.Equal(t, ClassTransactions, policies[0].Class)
+	assert.Equal(t, 3650, policies[0].RetentionDays)
+	assert.True(t, policies[0].IsDefault)
+
+	_, err = service.UpdatePolicy(ctx, festivalID, ClassTransactions, UpdatePolicyRequest{RetentionDays: 365}, nil)
+	assert.ErrorIs(t, err, ErrRetentionTooLow)
+	_, err = service.UpdatePolicy(ctx, festivalID, ClassAuditLogs, UpdatePolicyRequest{RetentionDays: MaxRetentionDays + 1}, nil)
+	assert.ErrorIs(t, err, ErrRetentionTooHigh)
+	_, err = service.UpdatePolicy(ctx, festivalID, "PHOTOS", UpdatePolicyRequest{RetentionDays: 30}, nil)
+	assert.ErrorIs(t, err, ErrUnknownClass)
+	mockRepo.AssertNotCalled(t, "SavePolicy", mock.Anything, mock.Anything)
+
+	organizerID := uuid.New()
+	mockRepo.On("SavePolicy", mock.Anything, mock.MatchedBy(func(policy *Policy) bool {
+		return policy.FestivalID == festivalID && policy.Class == ClassLocationPings && policy.RetentionDays == 7
+	})).Return(nil).Once()
+	policy, err := service.UpdatePolicy(ctx, festivalID, ClassLocationPings, UpdatePolicyRequest{RetentionDays: 7}, &organizerID)
+	require.NoError(t, err)
+	assert.Equal(t, 7, policy.RetentionDays)
+	assert.False(t, policy.IsDefault)
+	assert.Equal(t, ActionAnonymize, policy.Action)
+	assert.Equal(t, &organizerID, policy.UpdatedBy)
+
+	mockRepo.On("DeletePolicy", mock.Anything, festivalID, ClassLocationPings).Return(nil).Once()
+	require.NoError(t, service.ResetPolicy(ctx, festivalID, ClassLocationPings))
+	policies, err = service.ListPolicies(ctx, festivalID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, policies[2].RetentionDays)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPolicyService_EnforcePoliciesRecordsExecutions(t *testing.T) {
+	now := time.Date(2026, 7, 18, 3, 0, 0, 0, time.UTC)
+	festivalID := uuid.New()
+	mockRepo := NewMockPolicyRepository()
+	service := newTestPolicyService(mockRepo, now)
+	ctx := context.Background()
+
+	mockRepo.On("ListFestivalIDs", mock.Anything).Return([]uuid.UUID{festivalID}, nil).Once()
+	mockRepo.On("ListPolicies", mock.Anything, festivalID).
+		Return([]Policy{{FestivalID: festivalID, Class: ClassLocationPings, RetentionDays: 7}}, nil).Once()
+	scoped := mock.MatchedBy(func(id *uuid.UUID) bool { return id != nil && *id == festivalID })
+	// 2500 analytics events are past their retention, in three batches
+	mockRepo.On("Enforce", mock.Anything, ofClass(ClassAnalyticsEvents), scoped, mock.Anything, batchSize).Return(int64(batchSize), nil).Twice()
+	mockRepo.On("Enforce", mock.Anything, ofClass(ClassAnalyticsEvents), scoped, mock.Anything, batchSize).Return(int64(500), nil).Once()
+	mockRepo.On("Enforce", mock.Anything, ofClass(ClassAuditLogs), mock.Anything, mock.Anything, batchSize).Return(int64(0), errors.New("statement timeout"))
+	mockRepo.On("Enforce", mock.Anything, mock.Anything, mock.Anything, mock.Anything, batchSize).Return(int64(0), nil)
+	var executions []Execution
+	mockRepo.On("CreateExecution", mock.Anything, mock.AnythingOfType("*retention.Execution")).
+		Run(func(args mock.Arguments) { executions = append(executions, *args.Get(1).(*Execution)) }).
+		Return(nil)
+
+	affected, err := service.EnforcePolicies(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, int64(2500), affected)
+	mockRepo.AssertNumberOfCalls(t, "Enforce", len(Classes)+2+3)
+
+	// One execution per class of the festival, then the classes with data of no festival
+	require.Len(t, executions, len(Classes)+3)
+	byClass := map[DataClass]Execution{}
+	for _, execution := range executions[:len(Classes)] {
+		assert.Equal(t, &festivalID, execution.FestivalID)
+		byClass[execution.Class] = execution
+	}
+	assert.Equal(t, int64(2500), byClass[ClassAnalyticsEvents].Affected)
+	assert.Equal(t, now.AddDate(0, 0, -730), byClass[ClassAnalyticsEvents].Cutoff)
+	assert.Equal(t, now.AddDate(0, 0, -7), byClass[ClassLocationPings].Cutoff)
+	assert.Equal(t, now.AddDate(0, 0, -3650), byClass[ClassTransactions].Cutoff)
+	assert.Equal(t, ExecutionFailed, byClass[ClassAuditLogs].Status)
+	assert.Equal(t, "statement timeout", byClass[ClassAuditLogs].Error)
+	for _, execution := range executions[len(Classes):] {
+		assert.Nil(t, execution.FestivalID)
+		assert.NotEqual(t, ClassTransactions, execution.Class)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPolicyService_ComplianceReport(t *testing.T) {
+	now := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+	festivalID := uuid.New()
+	mockRepo := NewMockPolicyRepository()
+	service := newTestPolicyService(mockRepo, now)
+	ctx := context.Background()
+	mockRepo.On("ListPolicies", mock.Anything, festivalID).Return([]Policy(nil), nil).Twice()
+
+	// Audit logs one day past their retention are within the grace of the sweep
+	auditOldest := now.AddDate(0, 0, -91)
+	pingsOldest := now.AddDate(0, 0, -40)
+	mockRepo.On("OldestRecord", mock.Anything, ofClass(ClassAuditLogs), festivalID).Return(&auditOldest, nil)
+	mockRepo.On("OldestRecord", mock.Anything, ofClass(ClassLocationPings), festivalID).Return(nil, nil).Once()
+	mockRepo.On("OldestRecord", mock.Anything, ofClass(ClassLocationPings), festivalID).Return(&pingsOldest, nil).Once()
+	mockRepo.On("OldestRecord", mock.Anything, mock.Anything, festivalID).Return(nil, nil)
+
+	mockRepo.On("LatestExecutions", mock.Anything, festivalID).Return(map[DataClass]Execution{}, nil).Once()
+	report, err := service.ComplianceReport(ctx, festivalID)
+	require.NoError(t, err)
+	assert.True(t, report.Compliant)
+
+	// Location pings 40 days old are left after the last sweep
+	swept := Execution{ID: uuid.New(), FestivalID: &festivalID, Class: ClassLocationPings, Status: ExecutionSucceeded}
+	mockRepo.On("LatestExecutions", mock.Anything, festivalID).Return(map[DataClass]Execution{ClassLocationPings: swept}, nil).Once()
+	report, err = service.ComplianceReport(ctx, festivalID)
+	require.NoError(t, err)
+	assert.False(t, report.Compliant)
+	require.Len(t, report.Classes, len(Classes))
+	pings := report.Classes[2]
+	assert.Equal(t, ClassLocationPings, pings.Class)
+	assert.False(t, pings.Compliant)
+	require.NotNil(t, pings.LastExecution)
+	assert.Equal(t, ExecutionSucceeded, pings.LastExecution.Status)
+	assert.True(t, report.Classes[0].Compliant)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,43 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	// AnonymizeIPs truncates at most limit IPs of the target stored before a time, and
+	// returns how many were truncated
+	AnonymizeIPs(ctx context.Context, target Target, before time.Time, limit int) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) AnonymizeIPs(ctx context.Context, target Target, before time.Time, limit int) (int64, error) {
+	// Identifiers come from Targets, never from input
+	query := fmt.Sprintf(`
+		UPDATE public.%[1]s SET %[2]s = anonymize_ip(%[2]s)
+		WHERE id IN (
+			SELECT id FROM public.%[1]s
+			WHERE %[3]s < ?
+				AND %[2]s IS NOT NULL AND %[2]s <> ''
+				AND %[2]s IS DISTINCT FROM anonymize_ip(%[2]s)
+			LIMIT ?
+		)
+	`, target.Table, target.IPColumn, target.AtColumn)
+
+	result := r.db.WithContext(ctx).Exec(query, before, limit)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to anonymize IPs of %s: %w", target.Table, result.Error)
+	}
+	return result.RowsAffected, nil
+}
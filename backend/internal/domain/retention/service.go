@@ -0,0 +1,63 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// Service truncates the client IPs stored in the audit and impersonation logs once
+// they are older than the retention period, keeping the network for analytics
+type Service struct {
+	repo      Repository
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewService creates a new retention service keeping full IPs for a number of days;
+// 0 or less keeps them
+func NewService(repo Repository, retentionDays int) *Service {
+	return &Service{
+		repo:      repo,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// HandleAnonymizeIPs handles the daily sweep of the IPs past the retention period
+func (s *Service) HandleAnonymizeIPs(ctx context.Context, t *asynq.Task) error {
+	anonymized, err := s.AnonymizeIPs(ctx)
+	if err != nil {
+		return err
+	}
+	if anonymized > 0 {
+		log.Info().Int64("anonymized", anonymized).Dur("retention", s.retention).Msg("Anonymized stored client IPs")
+	}
+	return nil
+}
+
+// AnonymizeIPs truncates the IPs of every target stored before the retention period,
+// in batches, and returns how many were truncated
+func (s *Service) AnonymizeIPs(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	before := s.now().Add(-s.retention)
+	var total int64
+	for _, target := range Targets {
+		for {
+			anonymized, err := s.repo.AnonymizeIPs(ctx, target, before, batchSize)
+			if err != nil {
+				return total, err
+			}
+			total += anonymized
+			if anonymized < batchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,53 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	pending map[string]int64 // IPs left to anonymize per table
+	before  []time.Time
+}
+
+func (r *fakeRepository) AnonymizeIPs(ctx context.Context, target Target, before time.Time, limit int) (int64, error) {
+	r.before = append(r.before, before)
+	anonymized := r.pending[target.Table]
+	if anonymized > int64(limit) {
+		anonymized = int64(limit)
+	}
+	r.pending[target.Table] -= anonymized
+	return anonymized, nil
+}
+
+func TestService_AnonymizeIPs(t *testing.T) {
+	now := time.Date(2026, 7, 18, 2, 30, 0, 0, time.UTC)
+	repo := &fakeRepository{pending: map[string]int64{"audit_logs": 2500, "rbac_audit_logs": 3}}
+	service := NewService(repo, 30)
+	service.now = func() time.Time { return now }
+
+	anonymized, err := service.AnonymizeIPs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2503), anonymized)
+	assert.Zero(t, repo.pending["audit_logs"])
+
+	// Three batches of audit logs, then one per other table
+	assert.Len(t, repo.before, 3+len(Targets)-1)
+	for _, before := range repo.before {
+		assert.Equal(t, now.AddDate(0, 0, -30), before)
+	}
+}
+
+func TestService_AnonymizeIPsDisabled(t *testing.T) {
+	repo := &fakeRepository{pending: map[string]int64{"audit_logs": 10}}
+	service := NewService(repo, 0)
+
+	anonymized, err := service.AnonymizeIPs(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, anonymized)
+	assert.Empty(t, repo.before)
+}
@@ -0,0 +1,209 @@
+package runbook
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// Handler handles HTTP requests for the runbook actions
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new runbook handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin runbook routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	runbook := r.Group("/runbook")
+	{
+		runbook.POST("/festivals/:festivalId/aggregates/rebuild", h.RebuildAggregates)
+		runbook.POST("/festivals/:festivalId/webhooks/redeliver", h.RedeliverWebhooks)
+		runbook.POST("/festivals/:festivalId/devices/:deviceId/resync", h.ResyncDevice)
+		runbook.POST("/wallets/:walletId/recompute", h.RecomputeWallet)
+	}
+}
+
+// RebuildAggregates rebuilds the Redis aggregates of a festival
+// @Summary Rebuild festival aggregates
+// @Description Recompute the stand wait times, menu recommendations and preparation time models of a festival and drop its cached public stats. Dry run unless dryRun is false: only the entries of each aggregate are counted. Recorded in the audit log.
+// @Tags runbook
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body RunRequest true "Reason and dry run"
+// @Success 200 {object} response.Response{data=AggregatesResult} "Aggregates"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Security BearerAuth
+// @Router /admin/runbook/festivals/{festivalId}/aggregates/rebuild [post]
+func (h *Handler) RebuildAggregates(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req RunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.RebuildAggregates(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// RedeliverWebhooks sends again the failed webhooks of a festival day
+// @Summary Redeliver failed webhooks
+// @Description Send again the webhook payloads of a festival that failed during an operational day and were not delivered since, at most 500 per run. Dry run unless dryRun is false: the payloads are only listed. Recorded in the audit log.
+// @Tags runbook
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body RedeliverWebhooksRequest true "Day, reason and dry run"
+// @Success 200 {object} response.Response{data=WebhooksResult} "Redeliveries"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or date"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Failure 503 {object} response.ErrorResponse "Webhooks not configured"
+// @Security BearerAuth
+// @Router /admin/runbook/festivals/{festivalId}/webhooks/redeliver [post]
+func (h *Handler) RedeliverWebhooks(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req RedeliverWebhooksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.RedeliverWebhooks(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// ResyncDevice processes again the offline batches of a device
+// @Summary Resync an offline device
+// @Description Process again the offline batches of a device that are pending, stuck processing or failed. Transactions already processed are recognized and not charged twice. Dry run unless dryRun is false: the batches are only listed. Recorded in the audit log.
+// @Tags runbook
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param deviceId path string true "Device ID"
+// @Param request body RunRequest true "Reason and dry run"
+// @Success 200 {object} response.Response{data=sync.ResyncResult} "Batches"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Failure 503 {object} response.ErrorResponse "Offline sync not configured"
+// @Security BearerAuth
+// @Router /admin/runbook/festivals/{festivalId}/devices/{deviceId}/resync [post]
+func (h *Handler) ResyncDevice(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req RunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.ResyncDevice(c.Request.Context(), festivalID, c.Param("deviceId"), req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// RecomputeWallet sets a wallet balance to the sum of its ledger
+// @Summary Recompute a wallet balance
+// @Description Compare the balance of a wallet with the sum of its completed and refunded transactions, and set the balance to that sum when dryRun is false. Recorded in the audit log with the balance before and after.
+// @Tags runbook
+// @Accept json
+// @Produce json
+// @Param walletId path string true "Wallet ID" format(uuid)
+// @Param request body RunRequest true "Reason and dry run"
+// @Success 200 {object} response.Response{data=WalletRecompute} "Wallet balance and ledger"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Security BearerAuth
+// @Router /admin/runbook/wallets/{walletId}/recompute [post]
+func (h *Handler) RecomputeWallet(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("walletId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid wallet ID", nil)
+		return
+	}
+
+	var req RunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.RecomputeWallet(c.Request.Context(), walletID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrWalletNotFound):
+		response.NotFound(c, "Wallet not found")
+	case errors.Is(err, ErrInvalidDate):
+		response.BadRequest(c, "INVALID_DATE", err.Error(), nil)
+	case errors.Is(err, ErrActionUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
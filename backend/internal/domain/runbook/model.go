@@ -0,0 +1,118 @@
+package runbook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Runbook errors
+var (
+	ErrFestivalNotFound  = errors.New("festival not found")
+	ErrWalletNotFound    = errors.New("wallet not found")
+	ErrInvalidDate       = errors.New("date must be a day formatted YYYY-MM-DD")
+	ErrActionUnavailable = errors.New("this runbook action is not configured")
+)
+
+// MaxRedeliveries caps the webhook payloads sent again by one run; the next run sends
+// the rest
+const MaxRedeliveries = 500
+
+// Action is an operational fix of the runbook
+type Action string
+
+const (
+	ActionRebuildAggregates Action = "REBUILD_AGGREGATES"
+	ActionRedeliverWebhooks Action = "REDELIVER_WEBHOOKS"
+	ActionResyncDevice      Action = "RESYNC_DEVICE"
+	ActionRecomputeWallet   Action = "RECOMPUTE_WALLET"
+)
+
+// Redis aggregates of a festival
+const (
+	AggregateWaitTimes       = "wait_times"
+	AggregateRecommendations = "recommendations"
+	AggregateETAModels       = "eta_models"
+	AggregatePublicStats     = "public_stats"
+)
+
+// Festival is what the runbook needs of a festival
+type Festival struct {
+	ID          uuid.UUID
+	Slug        string
+	Timezone    string
+	DayStartsAt string
+}
+
+// RunRequest is the body of every runbook action
+type RunRequest struct {
+	DryRun *bool  `json:"dryRun,omitempty"`                        // Defaults to true: nothing changes unless set to false
+	Reason string `json:"reason" binding:"required,min=3,max=500"` // Recorded in the audit log
+}
+
+// IsDryRun reports whether the action should only show what it would do
+func (r RunRequest) IsDryRun() bool {
+	return r.DryRun == nil || *r.DryRun
+}
+
+// RedeliverWebhooksRequest sends again the webhook payloads of a day that failed
+type RedeliverWebhooksRequest struct {
+	RunRequest
+	Date string `json:"date" binding:"required"` // Operational day of the festival, YYYY-MM-DD
+}
+
+// AggregateState is a Redis aggregate of a festival before and after its rebuild
+type AggregateState struct {
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+	Entries      int64  `json:"entries"`                // Stands in the hash, or cached copies of the public stats
+	EntriesAfter *int64 `json:"entriesAfter,omitempty"` // Absent on a dry run
+	Error        string `json:"error,omitempty"`
+}
+
+// AggregatesResult is the outcome of rebuilding the Redis aggregates of a festival
+type AggregatesResult struct {
+	FestivalID uuid.UUID        `json:"festivalId"`
+	DryRun     bool             `json:"dryRun"`
+	Aggregates []AggregateState `json:"aggregates"`
+}
+
+// Redelivery is a webhook payload that failed to be delivered, and its redelivery
+type Redelivery struct {
+	DeliveryID   uuid.UUID  `json:"deliveryId"` // Last failed delivery of the payload
+	WebhookID    uuid.UUID  `json:"webhookId"`
+	WebhookName  string     `json:"webhookName"`
+	URL          string     `json:"url"`
+	Event        string     `json:"event"`
+	FailedAt     time.Time  `json:"failedAt"`
+	LastError    string     `json:"lastError,omitempty"`
+	RedeliveryID *uuid.UUID `json:"redeliveryId,omitempty"` // Absent on a dry run
+	Delivered    bool       `json:"delivered"`
+	Error        string     `json:"error,omitempty"` // Of the redelivery
+}
+
+// WebhooksResult is the outcome of sending again the failed webhooks of a day
+type WebhooksResult struct {
+	FestivalID uuid.UUID    `json:"festivalId"`
+	Date       string       `json:"date"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	DryRun     bool         `json:"dryRun"`
+	Deliveries []Redelivery `json:"deliveries"`
+	Delivered  int          `json:"delivered"`
+	Failed     int          `json:"failed"`
+	Truncated  bool         `json:"truncated"` // More than MaxRedeliveries failed; run again for the rest
+}
+
+// WalletRecompute is a wallet balance against the sum of its ledger
+type WalletRecompute struct {
+	WalletID      uuid.UUID `json:"walletId"`
+	FestivalID    uuid.UUID `json:"festivalId"`
+	Balance       int64     `json:"balance"`       // Before the recompute, in cents
+	LedgerBalance int64     `json:"ledgerBalance"` // Sum of the completed and refunded transactions
+	Difference    int64     `json:"difference"`    // Balance minus ledger balance
+	Transactions  int       `json:"transactions"`
+	DryRun        bool      `json:"dryRun"`
+	Corrected     bool      `json:"corrected"` // The balance was set to the ledger balance
+}
@@ -0,0 +1,112 @@
+package runbook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository reads the festivals and wallets the runbook acts on
+type Repository interface {
+	GetFestival(ctx context.Context, id uuid.UUID) (*Festival, error)
+	GetWalletLedger(ctx context.Context, walletID uuid.UUID) (*WalletRecompute, error)
+	RecomputeWallet(ctx context.Context, walletID uuid.UUID) (*WalletRecompute, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new runbook repository
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ledgerStatuses are the transaction statuses that moved a wallet balance, as the
+// reconciliation counts them
+var ledgerStatuses = []string{"COMPLETED", "REFUNDED"}
+
+func (r *repository) GetFestival(ctx context.Context, id uuid.UUID) (*Festival, error) {
+	var festivals []Festival
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT id, slug, COALESCE(timezone, '') AS timezone, day_starts_at
+		FROM public.festivals
+		WHERE id = ?`,
+		id,
+	).Scan(&festivals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(festivals) == 0 {
+		return nil, nil
+	}
+	return &festivals[0], nil
+}
+
+func (r *repository) GetWalletLedger(ctx context.Context, walletID uuid.UUID) (*WalletRecompute, error) {
+	return walletLedger(ctx, r.db, walletID)
+}
+
+// RecomputeWallet sets the balance of a wallet to the sum of its ledger. The wallet is
+// locked meanwhile, so payments waiting on it are counted once they are committed.
+func (r *repository) RecomputeWallet(ctx context.Context, walletID uuid.UUID) (*WalletRecompute, error) {
+	var recompute *WalletRecompute
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Table("wallets").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", walletID).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		var err error
+		if recompute, err = walletLedger(ctx, tx, walletID); err != nil {
+			return err
+		}
+		if recompute.Difference == 0 {
+			return nil
+		}
+
+		if err := tx.Table("wallets").Where("id = ?", walletID).Updates(map[string]interface{}{
+			"balance":    recompute.LedgerBalance,
+			"updated_at": gorm.Expr("NOW()"),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update wallet balance: %w", err)
+		}
+		recompute.Corrected = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recompute, nil
+}
+
+// walletLedger returns the balance of a wallet with the sum of its ledger, nil when the
+// wallet does not exist
+func walletLedger(ctx context.Context, db *gorm.DB, walletID uuid.UUID) (*WalletRecompute, error) {
+	var rows []WalletRecompute
+	err := db.WithContext(ctx).Raw(`
+		SELECT w.id AS wallet_id, w.festival_id, w.balance,
+			COALESCE(SUM(t.amount), 0) AS ledger_balance,
+			COUNT(t.id) AS transactions
+		FROM public.wallets w
+		LEFT JOIN public.transactions t ON t.wallet_id = w.id AND t.status IN ?
+		WHERE w.id = ?
+		GROUP BY w.id, w.festival_id, w.balance`,
+		ledgerStatuses, walletID,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet ledger: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	rows[0].Difference = rows[0].Balance - rows[0].LedgerBalance
+	return &rows[0], nil
+}
@@ -0,0 +1,386 @@
+package runbook
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/eta"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/sync"
+	"github.com/mimi6060/festivals/backend/internal/domain/webhooks"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// WaitTimes recomputes the stand wait times of a festival, satisfied by
+// order.WaitTimeService
+type WaitTimes interface {
+	Refresh(ctx context.Context, festivalID uuid.UUID) ([]order.WaitTimeEstimate, error)
+}
+
+// Recommendations recomputes the menu recommendations of a festival, satisfied by
+// recommendation.Service
+type Recommendations interface {
+	Refresh(ctx context.Context, festivalID uuid.UUID) error
+}
+
+// ETAModels retrains the preparation time models of a festival, satisfied by
+// eta.Service
+type ETAModels interface {
+	Train(ctx context.Context, festivalID uuid.UUID) ([]eta.StandModel, error)
+}
+
+// PublicStats drops the cached public stats of a festival, satisfied by
+// publicstats.Service
+type PublicStats interface {
+	Invalidate(ctx context.Context, festivalID uuid.UUID)
+}
+
+// Aggregates are the services that rebuild the Redis aggregates of a festival
+type Aggregates struct {
+	WaitTimes       WaitTimes
+	Recommendations Recommendations
+	ETAModels       ETAModels
+	PublicStats     PublicStats
+}
+
+// Webhooks sends failed webhook payloads again, satisfied by webhooks.Service
+type Webhooks interface {
+	FailedDeliveries(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]webhooks.WebhookDelivery, error)
+	Redeliver(ctx context.Context, failed *webhooks.WebhookDelivery) (*webhooks.WebhookDelivery, error)
+}
+
+// Devices processes the offline batches of a device again, satisfied by sync.Service
+type Devices interface {
+	ResyncDevice(ctx context.Context, festivalID uuid.UUID, deviceID string, dryRun bool) (*sync.ResyncResult, error)
+}
+
+// AuditLogger records the runbook runs, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service runs the fixes operators otherwise apply by hand during a live event. Every
+// action defaults to a dry run showing what it would change, and every run, dry or
+// not, is recorded in the audit log with its reason.
+type Service struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+	aggregates  Aggregates
+	webhooks    Webhooks
+	devices     Devices
+	auditLogger AuditLogger
+}
+
+// NewService creates a new runbook service. Without a Redis client the aggregates are
+// rebuilt without counting their entries.
+func NewService(repo Repository, redisClient *redis.Client, aggregates Aggregates) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+		aggregates:  aggregates,
+	}
+}
+
+// SetWebhooks enables the redelivery of failed webhooks
+func (s *Service) SetWebhooks(w Webhooks) {
+	s.webhooks = w
+}
+
+// SetDevices enables the resync of offline devices
+func (s *Service) SetDevices(d Devices) {
+	s.devices = d
+}
+
+// SetAuditLogger records every runbook run in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.auditLogger = logger
+}
+
+// RebuildAggregates recomputes the wait times, menu recommendations and preparation
+// time models of a festival and drops its cached public stats. A dry run only counts
+// the entries of each aggregate. An aggregate failing to rebuild does not stop the
+// others; its error is reported with it.
+func (s *Service) RebuildAggregates(ctx context.Context, festivalID uuid.UUID, req RunRequest, userID *uuid.UUID) (*AggregatesResult, error) {
+	festival, err := s.getFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AggregatesResult{FestivalID: festivalID, DryRun: req.IsDryRun(), Aggregates: []AggregateState{}}
+	for _, aggregate := range s.festivalAggregates(festival) {
+		if aggregate.rebuild == nil {
+			continue
+		}
+		state := AggregateState{Name: aggregate.name, Key: aggregate.keys[0]}
+		state.Entries = s.countEntries(ctx, aggregate.keys, aggregate.hash)
+
+		if !result.DryRun {
+			if err := aggregate.rebuild(ctx); err != nil {
+				log.Error().Err(err).Str("aggregate", aggregate.name).Str("festival_id", festivalID.String()).Msg("Failed to rebuild aggregate")
+				state.Error = err.Error()
+			}
+			after := s.countEntries(ctx, aggregate.keys, aggregate.hash)
+			state.EntriesAfter = &after
+		}
+		result.Aggregates = append(result.Aggregates, state)
+	}
+
+	s.audit(ctx, ActionRebuildAggregates, "festival", festivalID.String(), &festivalID, req, userID, map[string]interface{}{
+		"aggregates": result.Aggregates,
+	}, nil)
+
+	return result, nil
+}
+
+// festivalAggregate is a Redis aggregate of a festival and how to rebuild it
+type festivalAggregate struct {
+	name    string
+	keys    []string
+	hash    bool // Entries are the fields of a hash rather than keys
+	rebuild func(ctx context.Context) error
+}
+
+func (s *Service) festivalAggregates(festival *Festival) []festivalAggregate {
+	id := festival.ID
+	aggregates := []festivalAggregate{
+		{name: AggregateWaitTimes, keys: []string{s.keyBuilder.StandWaitTimesKey(id)}, hash: true},
+		{name: AggregateRecommendations, keys: []string{s.keyBuilder.StandRecommendationsKey(id)}, hash: true},
+		{name: AggregateETAModels, keys: []string{s.keyBuilder.StandETAModelsKey(id)}, hash: true},
+		{name: AggregatePublicStats, keys: []string{
+			s.keyBuilder.FestivalPublicStatsKey(id.String()),
+			s.keyBuilder.FestivalPublicStatsKey(festival.Slug),
+		}},
+	}
+
+	if w := s.aggregates.WaitTimes; w != nil {
+		aggregates[0].rebuild = func(ctx context.Context) error {
+			_, err := w.Refresh(ctx, id)
+			return err
+		}
+	}
+	if r := s.aggregates.Recommendations; r != nil {
+		aggregates[1].rebuild = func(ctx context.Context) error {
+			return r.Refresh(ctx, id)
+		}
+	}
+	if e := s.aggregates.ETAModels; e != nil {
+		aggregates[2].rebuild = func(ctx context.Context) error {
+			_, err := e.Train(ctx, id)
+			return err
+		}
+	}
+	if p := s.aggregates.PublicStats; p != nil {
+		aggregates[3].rebuild = func(ctx context.Context) error {
+			p.Invalidate(ctx, id)
+			return nil
+		}
+	}
+	return aggregates
+}
+
+// countEntries counts the fields of a hash, or how many of the keys exist
+func (s *Service) countEntries(ctx context.Context, keys []string, hash bool) int64 {
+	if s.redisClient == nil {
+		return 0
+	}
+	var count int64
+	var err error
+	if hash {
+		count, err = s.redisClient.HLen(ctx, keys[0]).Result()
+	} else {
+		count, err = s.redisClient.Exists(ctx, keys...).Result()
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("key", keys[0]).Msg("Failed to count aggregate entries")
+	}
+	return count
+}
+
+// RedeliverWebhooks sends again the webhook payloads of a festival that failed during
+// one of its operational days and were not delivered since. A dry run only lists them.
+func (s *Service) RedeliverWebhooks(ctx context.Context, festivalID uuid.UUID, req RedeliverWebhooksRequest, userID *uuid.UUID) (*WebhooksResult, error) {
+	if s.webhooks == nil {
+		return nil, ErrActionUnavailable
+	}
+	festival, err := s.getFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	from, to := tz.NewCalendar(festival.Timezone, festival.DayStartsAt).Bounds(date)
+	failed, err := s.webhooks.FailedDeliveries(ctx, festivalID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WebhooksResult{
+		FestivalID: festivalID,
+		Date:       req.Date,
+		From:       from,
+		To:         to,
+		DryRun:     req.IsDryRun(),
+		Deliveries: []Redelivery{},
+	}
+	if len(failed) > MaxRedeliveries {
+		failed = failed[:MaxRedeliveries]
+		result.Truncated = true
+	}
+
+	for i := range failed {
+		delivery := &failed[i]
+		redelivery := Redelivery{
+			DeliveryID: delivery.ID,
+			WebhookID:  delivery.WebhookID,
+			Event:      delivery.Event,
+			FailedAt:   delivery.DeliveredAt,
+		}
+		if delivery.Webhook != nil {
+			redelivery.WebhookName = delivery.Webhook.Name
+			redelivery.URL = delivery.Webhook.URL
+		}
+		if delivery.Error != nil {
+			redelivery.LastError = *delivery.Error
+		}
+
+		if !result.DryRun {
+			sent, err := s.webhooks.Redeliver(ctx, delivery)
+			if err != nil {
+				redelivery.Error = err.Error()
+			} else {
+				redelivery.RedeliveryID = &sent.ID
+				redelivery.Delivered = sent.Success
+				if sent.Error != nil {
+					redelivery.Error = *sent.Error
+				}
+			}
+			if redelivery.Delivered {
+				result.Delivered++
+			} else {
+				result.Failed++
+			}
+		}
+		result.Deliveries = append(result.Deliveries, redelivery)
+	}
+
+	s.audit(ctx, ActionRedeliverWebhooks, "festival", festivalID.String(), &festivalID, req.RunRequest, userID, map[string]interface{}{
+		"date":      req.Date,
+		"payloads":  len(result.Deliveries),
+		"delivered": result.Delivered,
+		"failed":    result.Failed,
+		"truncated": result.Truncated,
+	}, nil)
+
+	return result, nil
+}
+
+// ResyncDevice processes again the offline batches of a device that did not go
+// through. A dry run only lists them.
+func (s *Service) ResyncDevice(ctx context.Context, festivalID uuid.UUID, deviceID string, req RunRequest, userID *uuid.UUID) (*sync.ResyncResult, error) {
+	if s.devices == nil {
+		return nil, ErrActionUnavailable
+	}
+	if _, err := s.getFestival(ctx, festivalID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.devices.ResyncDevice(ctx, festivalID, deviceID, req.IsDryRun())
+	if err != nil {
+		return nil, err
+	}
+
+	summary := map[string]interface{}{"batches": len(result.Batches)}
+	unprocessed, processed, failed := 0, 0, 0
+	for _, batch := range result.Batches {
+		unprocessed += batch.Unprocessed
+		if batch.Result != nil {
+			processed += batch.Result.SuccessCount
+			failed += batch.Result.FailedCount
+		}
+	}
+	summary["unprocessed"] = unprocessed
+	if !result.DryRun {
+		summary["processed"] = processed
+		summary["failed"] = failed
+	}
+	s.audit(ctx, ActionResyncDevice, "device", deviceID, &festivalID, req, userID, summary, nil)
+
+	return result, nil
+}
+
+// RecomputeWallet sets the balance of a wallet to the sum of its completed and
+// refunded transactions. A dry run only compares them.
+func (s *Service) RecomputeWallet(ctx context.Context, walletID uuid.UUID, req RunRequest, userID *uuid.UUID) (*WalletRecompute, error) {
+	var recompute *WalletRecompute
+	var err error
+	if req.IsDryRun() {
+		recompute, err = s.repo.GetWalletLedger(ctx, walletID)
+	} else {
+		recompute, err = s.repo.RecomputeWallet(ctx, walletID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if recompute == nil {
+		return nil, ErrWalletNotFound
+	}
+	recompute.DryRun = req.IsDryRun()
+
+	var changes *audit.Changes
+	if recompute.Corrected {
+		changes = &audit.Changes{
+			Before: map[string]interface{}{"balance": recompute.Balance},
+			After:  map[string]interface{}{"balance": recompute.LedgerBalance},
+		}
+	}
+	s.audit(ctx, ActionRecomputeWallet, "wallet", walletID.String(), &recompute.FestivalID, req, userID, map[string]interface{}{
+		"balance":       recompute.Balance,
+		"ledgerBalance": recompute.LedgerBalance,
+		"difference":    recompute.Difference,
+		"corrected":     recompute.Corrected,
+	}, changes)
+
+	return recompute, nil
+}
+
+func (s *Service) getFestival(ctx context.Context, festivalID uuid.UUID) (*Festival, error) {
+	festival, err := s.repo.GetFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if festival == nil {
+		return nil, ErrFestivalNotFound
+	}
+	return festival, nil
+}
+
+// audit records a runbook run with its reason and outcome
+func (s *Service) audit(ctx context.Context, action Action, resource, resourceID string, festivalID *uuid.UUID, req RunRequest, userID *uuid.UUID, summary map[string]interface{}, changes *audit.Changes) {
+	if s.auditLogger == nil {
+		return
+	}
+	s.auditLogger.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+		UserID:     userID,
+		Action:     audit.ActionRunbookRun,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Changes:    changes,
+		FestivalID: festivalID,
+		Metadata: map[string]interface{}{
+			"runbookAction": string(action),
+			"dryRun":        req.IsDryRun(),
+			"reason":        req.Reason,
+			"summary":       summary,
+		},
+	})
+}
@@ -0,0 +1,265 @@
+package runbook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/sync"
+	"github.com/mimi6060/festivals/backend/internal/domain/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	festival   *Festival
+	wallet     *WalletRecompute
+	recomputed bool
+}
+
+func (r *fakeRepository) GetFestival(ctx context.Context, id uuid.UUID) (*Festival, error) {
+	if r.festival == nil || r.festival.ID != id {
+		return nil, nil
+	}
+	return r.festival, nil
+}
+
+func (r *fakeRepository) GetWalletLedger(ctx context.Context, walletID uuid.UUID) (*WalletRecompute, error) {
+	if r.wallet == nil {
+		return nil, nil
+	}
+	ledger := *r.wallet
+	return &ledger, nil
+}
+
+func (r *fakeRepository) RecomputeWallet(ctx context.Context, walletID uuid.UUID) (*WalletRecompute, error) {
+	r.recomputed = true
+	ledger, _ := r.GetWalletLedger(ctx, walletID)
+	if ledger != nil && ledger.Difference != 0 {
+		ledger.Corrected = true
+	}
+	return ledger, nil
+}
+
+type fakeWebhooks struct {
+	from, to    time.Time
+	failed      []webhooks.WebhookDelivery
+	redelivered []uuid.UUID
+}
+
+func (w *fakeWebhooks) FailedDeliveries(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]webhooks.WebhookDelivery, error) {
+	w.from, w.to = from, to
+	return w.failed, nil
+}
+
+func (w *fakeWebhooks) Redeliver(ctx context.Context, failed *webhooks.WebhookDelivery) (*webhooks.WebhookDelivery, error) {
+	w.redelivered = append(w.redelivered, failed.ID)
+	if failed.Event == "wallet.topup" {
+		return nil, errors.New("webhook not found")
+	}
+	return &webhooks.WebhookDelivery{ID: uuid.New(), WebhookID: failed.WebhookID, Success: true}, nil
+}
+
+type fakeDevices struct {
+	dryRun bool
+}
+
+func (d *fakeDevices) ResyncDevice(ctx context.Context, festivalID uuid.UUID, deviceID string, dryRun bool) (*sync.ResyncResult, error) {
+	d.dryRun = dryRun
+	batch := sync.ResyncedBatch{BatchID: uuid.New(), Status: sync.SyncStatusProcessing, TotalCount: 3, Unprocessed: 2}
+	if !dryRun {
+		batch.Result = &sync.SyncResult{TotalCount: 3, SuccessCount: 2, FailedCount: 1}
+	}
+	return &sync.ResyncResult{DeviceID: deviceID, DryRun: dryRun, Batches: []sync.ResyncedBatch{batch}}, nil
+}
+
+type fakeWaitTimes struct {
+	refreshed int
+	err       error
+}
+
+func (w *fakeWaitTimes) Refresh(ctx context.Context, festivalID uuid.UUID) ([]order.WaitTimeEstimate, error) {
+	w.refreshed++
+	return nil, w.err
+}
+
+type fakePublicStats struct {
+	invalidated int
+}
+
+func (p *fakePublicStats) Invalidate(ctx context.Context, festivalID uuid.UUID) {
+	p.invalidated++
+}
+
+type fakeAuditLogger struct {
+	logs []audit.CreateAuditLogRequest
+}
+
+func (l *fakeAuditLogger) LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest) {
+	l.logs = append(l.logs, req)
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func newTestFestival() *Festival {
+	return &Festival{ID: uuid.New(), Slug: "summer-fest", Timezone: "Europe/Brussels", DayStartsAt: "06:00"}
+}
+
+func TestRunRequest_IsDryRun(t *testing.T) {
+	assert.True(t, RunRequest{}.IsDryRun())
+	assert.True(t, RunRequest{DryRun: boolPtr(true)}.IsDryRun())
+	assert.False(t, RunRequest{DryRun: boolPtr(false)}.IsDryRun())
+}
+
+func TestService_RebuildAggregates(t *testing.T) {
+	festival := newTestFestival()
+	waitTimes := &fakeWaitTimes{err: errors.New("redis unavailable")}
+	publicStats := &fakePublicStats{}
+	auditLogger := &fakeAuditLogger{}
+	service := NewService(&fakeRepository{festival: festival}, nil, Aggregates{WaitTimes: waitTimes, PublicStats: publicStats})
+	service.SetAuditLogger(auditLogger)
+	userID := uuid.New()
+
+	// Dry run
+	result, err := service.RebuildAggregates(context.Background(), festival.ID, RunRequest{Reason: "stale wait times"}, &userID)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	require.Len(t, result.Aggregates, 2)
+	assert.Equal(t, AggregateWaitTimes, result.Aggregates[0].Name)
+	assert.Equal(t, AggregatePublicStats, result.Aggregates[1].Name)
+	assert.Nil(t, result.Aggregates[0].EntriesAfter)
+	assert.Zero(t, waitTimes.refreshed)
+	assert.Zero(t, publicStats.invalidated)
+
+	// Run: a failing aggregate does not stop the others
+	result, err = service.RebuildAggregates(context.Background(), festival.ID, RunRequest{DryRun: boolPtr(false), Reason: "stale wait times"}, &userID)
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	assert.Equal(t, "redis unavailable", result.Aggregates[0].Error)
+	assert.NotNil(t, result.Aggregates[1].EntriesAfter)
+	assert.Equal(t, 1, waitTimes.refreshed)
+	assert.Equal(t, 1, publicStats.invalidated)
+
+	require.Len(t, auditLogger.logs, 2)
+	log := auditLogger.logs[1]
+	assert.Equal(t, audit.ActionRunbookRun, log.Action)
+	assert.Equal(t, &userID, log.UserID)
+	assert.Equal(t, string(ActionRebuildAggregates), log.Metadata["runbookAction"])
+	assert.Equal(t, false, log.Metadata["dryRun"])
+	assert.Equal(t, "stale wait times", log.Metadata["reason"])
+
+	_, err = service.RebuildAggregates(context.Background(), uuid.New(), RunRequest{Reason: "stale"}, &userID)
+	assert.ErrorIs(t, err, ErrFestivalNotFound)
+}
+
+func TestService_RedeliverWebhooks(t *testing.T) {
+	festival := newTestFestival()
+	webhookID := uuid.New()
+	hooks := &fakeWebhooks{failed: []webhooks.WebhookDelivery{
+		{ID: uuid.New(), WebhookID: webhookID, Event: "order.completed", Webhook: &webhooks.Webhook{Name: "ERP", URL: "https://erp.example.com/hooks"}},
+		{ID: uuid.New(), WebhookID: webhookID, Event: "wallet.topup"},
+	}}
+	auditLogger := &fakeAuditLogger{}
+	service := NewService(&fakeRepository{festival: festival}, nil, Aggregates{})
+	service.SetAuditLogger(auditLogger)
+
+	req := RedeliverWebhooksRequest{RunRequest: RunRequest{Reason: "ERP was down"}, Date: "2026-07-18"}
+
+	// The day is the operational day of the festival
+	result, err := service.RedeliverWebhooks(context.Background(), festival.ID, req, nil)
+	assert.ErrorIs(t, err, ErrActionUnavailable)
+	assert.Nil(t, result)
+
+	service.SetWebhooks(hooks)
+	result, err = service.RedeliverWebhooks(context.Background(), festival.ID, req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 7, 18, 4, 0, 0, 0, time.UTC), hooks.from.UTC())
+	assert.Equal(t, time.Date(2026, 7, 19, 4, 0, 0, 0, time.UTC), hooks.to.UTC())
+	require.Len(t, result.Deliveries, 2)
+	assert.Equal(t, "ERP", result.Deliveries[0].WebhookName)
+	assert.Empty(t, hooks.redelivered)
+
+	req.DryRun = boolPtr(false)
+	result, err = service.RedeliverWebhooks(context.Background(), festival.ID, req, nil)
+	require.NoError(t, err)
+	assert.Len(t, hooks.redelivered, 2)
+	assert.Equal(t, 1, result.Delivered)
+	assert.Equal(t, 1, result.Failed)
+	assert.True(t, result.Deliveries[0].Delivered)
+	assert.NotNil(t, result.Deliveries[0].RedeliveryID)
+	assert.Equal(t, "webhook not found", result.Deliveries[1].Error)
+	assert.Len(t, auditLogger.logs, 2)
+
+	req.Date = "18/07/2026"
+	_, err = service.RedeliverWebhooks(context.Background(), festival.ID, req, nil)
+	assert.ErrorIs(t, err, ErrInvalidDate)
+}
+
+func TestService_ResyncDevice(t *testing.T) {
+	festival := newTestFestival()
+	devices := &fakeDevices{}
+	auditLogger := &fakeAuditLogger{}
+	service := NewService(&fakeRepository{festival: festival}, nil, Aggregates{})
+	service.SetDevices(devices)
+	service.SetAuditLogger(auditLogger)
+
+	result, err := service.ResyncDevice(context.Background(), festival.ID, "pos-12", RunRequest{DryRun: boolPtr(false), Reason: "stuck batch"}, nil)
+	require.NoError(t, err)
+	assert.False(t, devices.dryRun)
+	assert.Equal(t, "pos-12", result.DeviceID)
+
+	require.Len(t, auditLogger.logs, 1)
+	log := auditLogger.logs[0]
+	assert.Equal(t, "device", log.Resource)
+	assert.Equal(t, "pos-12", log.ResourceID)
+	summary := log.Metadata["summary"].(map[string]interface{})
+	assert.Equal(t, 2, summary["unprocessed"])
+	assert.Equal(t, 2, summary["processed"])
+	assert.Equal(t, 1, summary["failed"])
+}
+
+func TestService_RecomputeWallet(t *testing.T) {
+	walletID := uuid.New()
+	repo := &fakeRepository{wallet: &WalletRecompute{
+		WalletID:      walletID,
+		FestivalID:    uuid.New(),
+		Balance:       2500,
+		LedgerBalance: 2000,
+		Difference:    500,
+		Transactions:  4,
+	}}
+	auditLogger := &fakeAuditLogger{}
+	service := NewService(repo, nil, Aggregates{})
+	service.SetAuditLogger(auditLogger)
+
+	// Dry run
+	result, err := service.RecomputeWallet(context.Background(), walletID, RunRequest{Reason: "balance complaint"}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.False(t, result.Corrected)
+	assert.Equal(t, int64(500), result.Difference)
+	assert.False(t, repo.recomputed)
+	assert.Nil(t, auditLogger.logs[0].Changes)
+
+	result, err = service.RecomputeWallet(context.Background(), walletID, RunRequest{DryRun: boolPtr(false), Reason: "balance complaint"}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.Corrected)
+	assert.True(t, repo.recomputed)
+
+	require.Len(t, auditLogger.logs, 2)
+	changes := auditLogger.logs[1].Changes
+	require.NotNil(t, changes)
+	assert.Equal(t, int64(2500), changes.Before["balance"])
+	assert.Equal(t, int64(2000), changes.After["balance"])
+	assert.Equal(t, &repo.wallet.FestivalID, auditLogger.logs[1].FestivalID)
+
+	repo.wallet = nil
+	_, err = service.RecomputeWallet(context.Background(), walletID, RunRequest{Reason: "balance complaint"}, nil)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
@@ -30,6 +30,7 @@ func (h *Handler) RegisterFestivalRoutes(r *gin.RouterGroup) {
 	search := r.Group("/search")
 	{
 		search.GET("", h.SearchInFestival)
+		search.GET("/catalog", h.SearchCatalog)
 		search.GET("/suggestions", h.GetSuggestionsInFestival)
 	}
 }
@@ -118,6 +119,57 @@ func (h *Handler) SearchInFestival(c *gin.Context) {
 	response.OK(c, result)
 }
 
+// SearchCatalog searches the stands and products of a festival
+// @Summary Search festival stands and products
+// @Description Full-text search over stands and products with typo tolerance, ranked by relevance, including whether each stand is open
+// @Tags search
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param q query string true "Search query, e.g. vegan burger" minlength(1) maxlength(100)
+// @Param type query string false "Search type filter" Enums(all, stand, product) default(all)
+// @Param lang query string false "Query language (en, fr, nl, de); defaults to the Accept-Language header"
+// @Param category query string false "Stand or product category"
+// @Param open_only query bool false "Only return open stands and their products" default(false)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20) maximum(100)
+// @Success 200 {object} response.Response{data=SearchResponse} "Search results"
+// @Failure 400 {object} response.ErrorResponse "Invalid request parameters"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/search/catalog [get]
+func (h *Handler) SearchCatalog(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	var req SearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid search parameters", err.Error())
+		return
+	}
+
+	filters, err := req.ToFilters()
+	if err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid filter parameters", err.Error())
+		return
+	}
+
+	filters.FestivalID = &festivalID
+	if filters.Language == "" {
+		filters.Language = c.GetHeader("Accept-Language")
+	}
+
+	result, err := h.service.SearchCatalog(c.Request.Context(), filters)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, result)
+}
+
 // GetSuggestions returns search autocomplete suggestions
 // @Summary Get search suggestions
 // @Description Get autocomplete suggestions based on partial query
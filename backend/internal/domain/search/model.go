@@ -1,6 +1,7 @@
 package search
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,6 +33,8 @@ type SearchFilters struct {
 	Type       SearchType `json:"type" form:"type"`
 	FestivalID *uuid.UUID `json:"festivalId,omitempty" form:"festival_id"`
 	Category   string     `json:"category,omitempty" form:"category"`
+	Language   string     `json:"language,omitempty" form:"lang"`      // Text search configuration, see SearchConfig
+	OpenOnly   bool       `json:"openOnly,omitempty" form:"open_only"` // Catalog search: skip closed stands
 	Page       int        `json:"page" form:"page"`
 	PerPage    int        `json:"perPage" form:"per_page"`
 }
@@ -60,7 +63,38 @@ func (f *SearchFilters) CacheKey() string {
 	if f.FestivalID != nil {
 		festivalPart = f.FestivalID.String()
 	}
-	return festivalPart + ":" + string(f.Type) + ":" + f.Query + ":" + f.Category
+	return festivalPart + ":" + string(f.Type) + ":" + f.Query + ":" + f.Category + ":" + f.Language
+}
+
+// searchConfigs maps language codes to the PostgreSQL text search configurations
+// that have a matching expression index (see migration 000023)
+var searchConfigs = map[string]string{
+	"en": "english",
+	"fr": "french",
+	"nl": "dutch",
+	"de": "german",
+}
+
+// DefaultSearchConfig is used when the language is unknown; it does not stem words
+const DefaultSearchConfig = "simple"
+
+// SearchConfig returns the text search configuration for a language code such as
+// "fr" or "fr-BE". Only whitelisted configurations are returned, so the result is
+// safe to inline in SQL.
+func SearchConfig(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_,;"); i > 0 {
+		lang = lang[:i]
+	}
+	if cfg, ok := searchConfigs[lang]; ok {
+		return cfg
+	}
+	for _, cfg := range searchConfigs {
+		if cfg == lang {
+			return cfg
+		}
+	}
+	return DefaultSearchConfig
 }
 
 // SearchResult represents a single search result
@@ -87,6 +121,7 @@ type ResultMeta struct {
 	// Stand-specific fields
 	StandCategory string `json:"standCategory,omitempty"`
 	StandStatus   string `json:"standStatus,omitempty"`
+	StandOpen     *bool  `json:"standOpen,omitempty"` // Set by the catalog search for stands and products
 
 	// Product-specific fields
 	ProductCategory string `json:"productCategory,omitempty"`
@@ -137,6 +172,8 @@ type SearchRequest struct {
 	Type       string `form:"type"`
 	FestivalID string `form:"festival_id"`
 	Category   string `form:"category"`
+	Language   string `form:"lang"`
+	OpenOnly   bool   `form:"open_only"`
 	Page       int    `form:"page"`
 	PerPage    int    `form:"per_page"`
 }
@@ -147,6 +184,8 @@ func (r *SearchRequest) ToFilters() (*SearchFilters, error) {
 		Query:    r.Query,
 		Type:     SearchType(r.Type),
 		Category: r.Category,
+		Language: r.Language,
+		OpenOnly: r.OpenOnly,
 		Page:     r.Page,
 		PerPage:  r.PerPage,
 	}
@@ -3,6 +3,7 @@ package search
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -27,6 +28,10 @@ type Repository interface {
 
 	// GetSuggestions returns search suggestions based on partial query
 	GetSuggestions(ctx context.Context, query string, festivalID *uuid.UUID, limit int) ([]SearchSuggestion, error)
+
+	// SearchCatalog searches the stands and products of a festival in the filter language,
+	// tolerating typos in names, and includes closed stands unless OpenOnly is set
+	SearchCatalog(ctx context.Context, filters *SearchFilters) ([]SearchResult, int64, error)
 }
 
 type repository struct {
@@ -801,6 +806,179 @@ func (r *repository) GetSuggestions(ctx context.Context, query string, festivalI
 	return suggestions, nil
 }
 
+// catalogTrigramThreshold is the minimum word similarity for a name to match a
+// misspelled query ("burgr" -> "Vegan Burger")
+const catalogTrigramThreshold = 0.4
+
+// standDocument and productDocument must stay identical to the expression indexes
+// created in migration 000023, otherwise PostgreSQL cannot use them
+const (
+	standDocument = `setweight(to_tsvector('%[1]s', COALESCE(s.name, '')), 'A') ||
+		setweight(to_tsvector('%[1]s', COALESCE(s.description, '')), 'B') ||
+		setweight(to_tsvector('%[1]s', COALESCE(s.location, '')), 'C')`
+
+	productDocument = `setweight(to_tsvector('%[1]s', COALESCE(p.name, '')), 'A') ||
+		setweight(to_tsvector('%[1]s', COALESCE(p.description, '')), 'B') ||
+		setweight(to_tsvector('%[1]s', COALESCE(p.category, '')), 'C')`
+)
+
+// SearchCatalog searches stands and products of a festival. A row matches when the
+// full-text query matches its document or when the query is close to its name
+// (trigram word similarity). Full-text rank and name similarity are summed so exact
+// matches come first and typo matches still show up.
+func (r *repository) SearchCatalog(ctx context.Context, filters *SearchFilters) ([]SearchResult, int64, error) {
+	if filters.FestivalID == nil {
+		return nil, 0, ErrFestivalRequired
+	}
+
+	// The configuration comes from the SearchConfig whitelist, so it can be inlined
+	// to let the planner pick the matching per-language index
+	cfg := SearchConfig(filters.Language)
+	standDoc := fmt.Sprintf(standDocument, cfg)
+	productDoc := fmt.Sprintf(productDocument, cfg)
+
+	// Closed stands are still returned, flagged, so attendees know where an item is sold
+	standStatus := "IN ('ACTIVE', 'CLOSED')"
+	if filters.OpenOnly {
+		standStatus = "= 'ACTIVE'"
+	}
+
+	var parts []string
+
+	if filters.Type == SearchTypeAll || filters.Type == SearchTypeStand {
+		part := fmt.Sprintf(`
+			SELECT
+				s.id,
+				'stand' as type,
+				s.name,
+				COALESCE(s.description, '') as description,
+				COALESCE(s.image_url, '') as image_url,
+				s.category as category,
+				''::text as stand_id,
+				''::text as stand_name,
+				s.status as stand_status,
+				0::bigint as price,
+				ts_rank(%[1]s, websearch_to_tsquery('%[2]s', @q)) + word_similarity(@q, s.name) as score
+			FROM stands s
+			WHERE s.festival_id = @festival AND s.status %[3]s
+				AND ((%[1]s) @@ websearch_to_tsquery('%[2]s', @q) OR @q <%% s.name)`,
+			standDoc, cfg, standStatus)
+		if filters.Category != "" {
+			part += ` AND s.category = @category`
+		}
+		parts = append(parts, part)
+	}
+
+	if filters.Type == SearchTypeAll || filters.Type == SearchTypeProduct {
+		part := fmt.Sprintf(`
+			SELECT
+				p.id,
+				'product' as type,
+				p.name,
+				COALESCE(p.description, '') as description,
+				COALESCE(p.image_url, '') as image_url,
+				p.category as category,
+				st.id::text as stand_id,
+				st.name as stand_name,
+				st.status as stand_status,
+				p.price as price,
+				ts_rank(%[1]s, websearch_to_tsquery('%[2]s', @q)) + word_similarity(@q, p.name) as score
+			FROM products p
+			INNER JOIN stands st ON p.stand_id = st.id
+			WHERE st.festival_id = @festival AND p.status = 'ACTIVE' AND st.status %[3]s
+				AND ((%[1]s) @@ websearch_to_tsquery('%[2]s', @q) OR @q <%% p.name)`,
+			productDoc, cfg, standStatus)
+		if filters.Category != "" {
+			part += ` AND p.category = @category`
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return nil, 0, fmt.Errorf("invalid catalog search type: %s", filters.Type)
+	}
+
+	union := strings.Join(parts, "\n\t\t\tUNION ALL\n")
+	args := map[string]interface{}{
+		"q":        filters.Query,
+		"festival": *filters.FestivalID,
+		"category": filters.Category,
+		"limit":    filters.PerPage,
+		"offset":   filters.Offset(),
+	}
+
+	var results []SearchResult
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// <% uses this threshold and, unlike word_similarity(), can use the trigram indexes
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %.2f", catalogTrigramThreshold)).Error; err != nil {
+			return fmt.Errorf("failed to set similarity threshold: %w", err)
+		}
+
+		countQuery := "SELECT COUNT(*) FROM (" + union + ") catalog"
+		if err := tx.Raw(countQuery, args).Scan(&total).Error; err != nil {
+			return fmt.Errorf("failed to count catalog results: %w", err)
+		}
+
+		searchQuery := "SELECT * FROM (" + union + ") catalog ORDER BY score DESC, name ASC LIMIT @limit OFFSET @offset"
+		rows, err := tx.Raw(searchQuery, args).Rows()
+		if err != nil {
+			return fmt.Errorf("failed to search catalog: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			var entityType, name, description, imageURL, category, standID, standName, standStatus string
+			var price int64
+			var score float64
+
+			if err := rows.Scan(&id, &entityType, &name, &description, &imageURL, &category, &standID, &standName, &standStatus, &price, &score); err != nil {
+				return fmt.Errorf("failed to scan catalog result: %w", err)
+			}
+
+			open := standStatus == "ACTIVE"
+			result := SearchResult{
+				ID:          id,
+				Type:        SearchType(entityType),
+				Name:        name,
+				Description: description,
+				ImageURL:    imageURL,
+				Score:       score,
+			}
+
+			if result.Type == SearchTypeStand {
+				result.Metadata = ResultMeta{
+					StandCategory: category,
+					StandStatus:   standStatus,
+					StandOpen:     &open,
+					FestivalID:    filters.FestivalID.String(),
+				}
+			} else {
+				result.Metadata = ResultMeta{
+					ProductCategory: category,
+					Price:           price,
+					PriceDisplay:    formatPrice(price),
+					StandID:         standID,
+					StandName:       standName,
+					StandStatus:     standStatus,
+					StandOpen:       &open,
+					FestivalID:      filters.FestivalID.String(),
+				}
+			}
+
+			results = append(results, result)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
 // formatPrice formats price in cents to a display string
 func formatPrice(cents int64) string {
 	dollars := float64(cents) / 100
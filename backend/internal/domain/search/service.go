@@ -148,6 +148,48 @@ func (s *Service) SearchProducts(ctx context.Context, query string, festivalID *
 	return s.Search(ctx, filters)
 }
 
+// SearchCatalog searches the stands and products of a festival, e.g. "vegan burger".
+// Results are ranked by relevance and flag whether the stand is currently open.
+func (s *Service) SearchCatalog(ctx context.Context, filters *SearchFilters) (*SearchResponse, error) {
+	startTime := time.Now()
+
+	if filters.FestivalID == nil {
+		return nil, ErrFestivalRequired
+	}
+
+	filters.Normalize()
+	if filters.Type != SearchTypeAll && filters.Type != SearchTypeStand && filters.Type != SearchTypeProduct {
+		filters.Type = SearchTypeAll
+	}
+	filters.Language = SearchConfig(filters.Language)
+
+	key := s.buildCatalogCacheKey(filters)
+	if s.redisClient != nil {
+		if cached, err := s.getResponseFromCache(ctx, key); err == nil && cached != nil {
+			return cached, nil
+		}
+	}
+
+	results, total, err := s.repo.SearchCatalog(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("catalog search failed: %w", err)
+	}
+
+	if results == nil {
+		results = []SearchResult{}
+	}
+
+	response := NewSearchResponse(filters, results, total, time.Since(startTime))
+
+	if s.redisClient != nil {
+		if err := s.setResponseCache(ctx, key, response); err != nil {
+			log.Warn().Err(err).Msg("Failed to cache catalog search results")
+		}
+	}
+
+	return response, nil
+}
+
 // GetSuggestions returns search suggestions based on partial query
 func (s *Service) GetSuggestions(ctx context.Context, query string, festivalID *uuid.UUID) (*SuggestionsResponse, error) {
 	if len(query) < 2 {
@@ -228,6 +270,21 @@ func (s *Service) buildSearchCacheKey(filters *SearchFilters) string {
 	)
 }
 
+// buildCatalogCacheKey shares the search prefix so InvalidateCache also clears catalog results
+func (s *Service) buildCatalogCacheKey(filters *SearchFilters) string {
+	return fmt.Sprintf("%s:search:%s:catalog:%s:%s:%s:%s:%t:%d:%d",
+		s.keyBuilder.FestivalKey(uuid.Nil),
+		filters.FestivalID.String(),
+		filters.Type,
+		filters.Language,
+		sanitizeQuery(filters.Query),
+		filters.Category,
+		filters.OpenOnly,
+		filters.Page,
+		filters.PerPage,
+	)
+}
+
 func (s *Service) buildSuggestionsCacheKey(query string, festivalID *uuid.UUID) string {
 	festivalPart := "global"
 	if festivalID != nil {
@@ -278,6 +335,29 @@ func (s *Service) setCache(ctx context.Context, filters *SearchFilters, response
 	return s.redisClient.Set(ctx, key, data, searchCacheTTL).Err()
 }
 
+func (s *Service) getResponseFromCache(ctx context.Context, key string) (*SearchResponse, error) {
+	data, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var response SearchResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (s *Service) setResponseCache(ctx context.Context, key string, response *SearchResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.Set(ctx, key, data, searchCacheTTL).Err()
+}
+
 func (s *Service) getSuggestionsFromCache(ctx context.Context, query string, festivalID *uuid.UUID) (*SuggestionsResponse, error) {
 	key := s.buildSuggestionsCacheKey(query, festivalID)
 	data, err := s.redisClient.Get(ctx, key).Bytes()
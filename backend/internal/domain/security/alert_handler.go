@@ -83,7 +83,7 @@ func (h *Handler) CreateSOSAlert(c *gin.Context) {
 
 	var req SOSRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -250,7 +250,7 @@ func (h *Handler) CreateSecurityAlert(c *gin.Context) {
 
 	var req CreateAlertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -310,7 +310,7 @@ func (h *Handler) UpdateAlert(c *gin.Context) {
 
 	var req UpdateAlertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -404,7 +404,7 @@ func (h *Handler) AssignAlert(c *gin.Context) {
 		AssignedTo uuid.UUID `json:"assignedTo" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -456,7 +456,7 @@ func (h *Handler) ResolveAlert(c *gin.Context) {
 
 	var req ResolveAlertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -510,7 +510,7 @@ func (h *Handler) CancelAlert(c *gin.Context) {
 		Reason string `json:"reason" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -621,7 +621,7 @@ func (h *Handler) CreateZone(c *gin.Context) {
 
 	var req CreateZoneRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -681,7 +681,7 @@ func (h *Handler) UpdateZone(c *gin.Context) {
 
 	var req UpdateZoneRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -0,0 +1,586 @@
+package sensor
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped device and sensor management, which
+// should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	devices := r.Group("/sensor-devices")
+	{
+		devices.GET("", h.ListDevices)
+		devices.POST("", h.CreateDevice)
+		devices.DELETE("/:deviceId", h.DeleteDevice)
+		devices.POST("/:deviceId/key", h.RotateAPIKey)
+	}
+
+	sensors := r.Group("/sensors")
+	{
+		sensors.POST("", h.CreateSensor)
+		sensors.PATCH("/:sensorId", h.UpdateSensor)
+		sensors.DELETE("/:sensorId", h.DeleteSensor)
+	}
+}
+
+// RegisterStaffRoutes registers the telemetry, alerts and restock tasks read by stand
+// managers, which should be restricted to staff
+func (h *Handler) RegisterStaffRoutes(r *gin.RouterGroup) {
+	r.GET("/sensors", h.ListSensors)
+	r.GET("/sensors/:sensorId/history", h.History)
+	r.GET("/sensor-alerts", h.ListAlerts)
+
+	tasks := r.Group("/restock-tasks")
+	{
+		tasks.GET("", h.ListTasks)
+		tasks.POST("/:taskId/complete", h.CompleteTask)
+	}
+}
+
+// RegisterDeviceRoutes registers the telemetry ingestion of the sensor devices,
+// authenticated with the API key of each device
+func (h *Handler) RegisterDeviceRoutes(r *gin.RouterGroup) {
+	device := r.Group("")
+	device.Use(h.authenticateDevice)
+	{
+		device.POST("/readings", h.Ingest)
+	}
+}
+
+// ListDevices lists the sensor devices of the festival
+// @Summary List sensor devices
+// @Description List the sensor gateways of the stands with when they last reported
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the devices of this stand" format(uuid)
+// @Success 200 {object} response.Response{data=[]Device} "Sensor devices"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensor-devices [get]
+func (h *Handler) ListDevices(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return
+	}
+
+	devices, err := h.service.ListDevices(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, devices)
+}
+
+// CreateDevice registers a sensor device of a stand
+// @Summary Register sensor device
+// @Description Register the sensor gateway of a stand. The API key it sends readings with is only returned once.
+// @Tags sensors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateDeviceRequest true "Device"
+// @Success 201 {object} response.Response{data=DeviceWithKey} "Device registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensor-devices [post]
+func (h *Handler) CreateDevice(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	device, err := h.service.CreateDevice(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, device)
+}
+
+// DeleteDevice deletes a sensor device
+// @Summary Delete sensor device
+// @Description Delete a sensor gateway with its sensors, their readings, alerts and restock tasks
+// @Tags sensors
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param deviceId path string true "Device ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Device not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensor-devices/{deviceId} [delete]
+func (h *Handler) DeleteDevice(c *gin.Context) {
+	festivalID, deviceID, ok := pathParams(c, "deviceId", "device")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteDevice(c.Request.Context(), festivalID, deviceID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// RotateAPIKey replaces the API key of a sensor device
+// @Summary Rotate sensor device API key
+// @Description Issue a new API key for a sensor gateway; the previous key stops working immediately
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param deviceId path string true "Device ID" format(uuid)
+// @Success 200 {object} response.Response{data=DeviceWithKey} "New API key"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Device not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensor-devices/{deviceId}/key [post]
+func (h *Handler) RotateAPIKey(c *gin.Context) {
+	festivalID, deviceID, ok := pathParams(c, "deviceId", "device")
+	if !ok {
+		return
+	}
+
+	device, err := h.service.RotateAPIKey(c.Request.Context(), festivalID, deviceID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, device)
+}
+
+// CreateSensor registers a sensor of a device
+// @Summary Register sensor
+// @Description Register a fridge temperature or keg level sensor of a device, with the thresholds that raise alerts. Kegs below minValue also open a restock task.
+// @Tags sensors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateSensorRequest true "Sensor"
+// @Success 201 {object} response.Response{data=SensorResponse} "Sensor registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Device or product not found"
+// @Failure 409 {object} response.ErrorResponse "Duplicate externalId"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensors [post]
+func (h *Handler) CreateSensor(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateSensorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	sensor, err := h.service.CreateSensor(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, sensor)
+}
+
+// UpdateSensor updates a sensor
+// @Summary Update sensor
+// @Description Update the name, keg product, thresholds or enabled flag of a sensor. Readings of disabled sensors are rejected.
+// @Tags sensors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param sensorId path string true "Sensor ID" format(uuid)
+// @Param request body UpdateSensorRequest true "Changes"
+// @Success 200 {object} response.Response{data=SensorResponse} "Sensor updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Sensor or product not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensors/{sensorId} [patch]
+func (h *Handler) UpdateSensor(c *gin.Context) {
+	festivalID, sensorID, ok := pathParams(c, "sensorId", "sensor")
+	if !ok {
+		return
+	}
+
+	var req UpdateSensorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	sensor, err := h.service.UpdateSensor(c.Request.Context(), festivalID, sensorID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, sensor)
+}
+
+// DeleteSensor deletes a sensor
+// @Summary Delete sensor
+// @Description Delete a sensor with its readings, alerts and restock tasks
+// @Tags sensors
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param sensorId path string true "Sensor ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Sensor not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensors/{sensorId} [delete]
+func (h *Handler) DeleteSensor(c *gin.Context) {
+	festivalID, sensorID, ok := pathParams(c, "sensorId", "sensor")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSensor(c.Request.Context(), festivalID, sensorID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListSensors lists the sensors of the festival
+// @Summary List sensors
+// @Description List the fridge and keg sensors with their last reading and whether they reported in the last 10 minutes
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the sensors of this stand" format(uuid)
+// @Success 200 {object} response.Response{data=[]SensorResponse} "Sensors"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensors [get]
+func (h *Handler) ListSensors(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return
+	}
+
+	sensors, err := h.service.ListSensors(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, sensors)
+}
+
+// History returns the telemetry history of a sensor
+// @Summary Sensor history
+// @Description Readings of a sensor aggregated per interval (min, max, average), over the last 24 hours by default and at most 7 days
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param sensorId path string true "Sensor ID" format(uuid)
+// @Param from query string false "Start (RFC 3339)"
+// @Param to query string false "End (RFC 3339), now by default"
+// @Param interval query string false "Aggregation interval" Enums(1m, 5m, 15m, 1h) default(5m)
+// @Success 200 {object} response.Response{data=History} "History"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Sensor not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensors/{sensorId}/history [get]
+func (h *Handler) History(c *gin.Context) {
+	festivalID, sensorID, ok := pathParams(c, "sensorId", "sensor")
+	if !ok {
+		return
+	}
+
+	var query HistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	history, err := h.service.History(c.Request.Context(), festivalID, sensorID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, history)
+}
+
+// ListAlerts lists the sensor alerts of the festival
+// @Summary List sensor alerts
+// @Description List the sensors that left their thresholds, latest first
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(OPEN, RESOLVED)
+// @Param standId query string false "Only the alerts of this stand" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Alert,meta=response.Meta} "Sensor alerts"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/sensor-alerts [get]
+func (h *Handler) ListAlerts(c *gin.Context) {
+	festivalID, filter, ok := listParams(c)
+	if !ok {
+		return
+	}
+
+	alerts, total, err := h.service.ListAlerts(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, alerts, &response.Meta{
+		Total:   int(total),
+		Page:    filter.Offset/filter.Limit + 1,
+		PerPage: filter.Limit,
+	})
+}
+
+// ListTasks lists the restock tasks of the festival
+// @Summary List restock tasks
+// @Description List the kegs to replace, opened when a keg sensor reads below its minimum, latest first
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(OPEN, DONE)
+// @Param standId query string false "Only the tasks of this stand" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]RestockTask,meta=response.Meta} "Restock tasks"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-tasks [get]
+func (h *Handler) ListTasks(c *gin.Context) {
+	festivalID, filter, ok := listParams(c)
+	if !ok {
+		return
+	}
+
+	tasks, total, err := h.service.ListTasks(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, tasks, &response.Meta{
+		Total:   int(total),
+		Page:    filter.Offset/filter.Limit + 1,
+		PerPage: filter.Limit,
+	})
+}
+
+// CompleteTask marks a restock task done
+// @Summary Complete restock task
+// @Description Record that the staff replaced the keg of a restock task
+// @Tags sensors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param taskId path string true "Restock task ID" format(uuid)
+// @Success 200 {object} response.Response{data=RestockTask} "Task done"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Restock task not found"
+// @Failure 409 {object} response.ErrorResponse "Task already done"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/restock-tasks/{taskId}/complete [post]
+func (h *Handler) CompleteTask(c *gin.Context) {
+	festivalID, taskID, ok := pathParams(c, "taskId", "restock task")
+	if !ok {
+		return
+	}
+
+	var completedBy *uuid.UUID
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		completedBy = &id
+	}
+
+	task, err := h.service.CompleteTask(c.Request.Context(), festivalID, taskID, completedBy)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, task)
+}
+
+// Ingest stores a batch of readings of the device
+// @Summary Send sensor readings
+// @Description Batch of up to 500 readings of the sensors of a device, identified by their externalId. Readings of unknown or disabled sensors, older than 24 hours or more than 5 minutes in the future are rejected; the others are stored and checked against the thresholds.
+// @Tags sensors
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "Device API key"
+// @Param request body IngestRequest true "Readings"
+// @Success 200 {object} response.Response{data=IngestResult} "Readings stored"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Invalid API key"
+// @Router /sensor-gateway/readings [post]
+func (h *Handler) Ingest(c *gin.Context) {
+	var req IngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	result, err := h.service.Ingest(c.Request.Context(), c.MustGet("sensor_device").(*Device), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// authenticateDevice resolves the device of the API key, sent in the X-API-Key header
+// or with the ApiKey authorization scheme
+func (h *Handler) authenticateDevice(c *gin.Context) {
+	key := c.GetHeader("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(c.GetHeader("Authorization"), "ApiKey ")
+	}
+
+	device, err := h.service.AuthenticateDevice(c.Request.Context(), key)
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set("sensor_device", device)
+	c.Next()
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func pathParams(c *gin.Context, param, name string) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid "+name+" ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, id, true
+}
+
+func listParams(c *gin.Context) (uuid.UUID, ListFilter, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, ListFilter{}, false
+	}
+	standID, ok := optionalUUID(c, "standId")
+	if !ok {
+		return uuid.Nil, ListFilter{}, false
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	return festivalID, ListFilter{
+		Status:  c.Query("status"),
+		StandID: standID,
+		Offset:  (page - 1) * perPage,
+		Limit:   perPage,
+	}, true
+}
+
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid "+name, nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidAPIKey):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, ErrDeviceNotFound):
+		response.NotFound(c, "Sensor device not found")
+	case errors.Is(err, ErrSensorNotFound):
+		response.NotFound(c, "Sensor not found")
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	case errors.Is(err, ErrProductNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrTaskNotFound):
+		response.NotFound(c, "Restock task not found")
+	case errors.Is(err, ErrInvalidKind), errors.Is(err, ErrInvalidThresholds):
+		response.BadRequest(c, "INVALID_SENSOR", err.Error(), nil)
+	case errors.Is(err, ErrEmptyBatch), errors.Is(err, ErrBatchTooLarge):
+		response.BadRequest(c, "INVALID_BATCH", err.Error(), nil)
+	case errors.Is(err, ErrInvalidInterval), errors.Is(err, ErrInvalidRange):
+		response.BadRequest(c, "INVALID_RANGE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidStatus):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrDuplicateSensor):
+		response.Conflict(c, "DUPLICATE_SENSOR", err.Error())
+	case errors.Is(err, ErrTaskClosed):
+		response.Conflict(c, "TASK_DONE", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
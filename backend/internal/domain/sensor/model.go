@@ -0,0 +1,292 @@
+package sensor
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sensor errors
+var (
+	ErrDeviceNotFound    = errors.New("sensor device not found")
+	ErrSensorNotFound    = errors.New("sensor not found")
+	ErrStandNotFound     = errors.New("stand not found")
+	ErrProductNotFound   = errors.New("product not found at the stand")
+	ErrTaskNotFound      = errors.New("restock task not found")
+	ErrInvalidKind       = errors.New("kind must be TEMPERATURE or KEG_LEVEL")
+	ErrInvalidThresholds = errors.New("minValue must be below maxValue")
+	ErrDuplicateSensor   = errors.New("the device already has a sensor with this externalId")
+	ErrInvalidAPIKey     = errors.New("invalid sensor API key")
+	ErrEmptyBatch        = errors.New("a batch needs at least one reading")
+	ErrBatchTooLarge     = errors.New("too many readings in the batch")
+	ErrInvalidInterval   = errors.New("interval must be 1m, 5m, 15m or 1h")
+	ErrInvalidRange      = errors.New("from must be before to, at most 7 days apart")
+	ErrTaskClosed        = errors.New("restock task is already done")
+	ErrInvalidStatus     = errors.New("unknown status")
+)
+
+// Ingestion and history limits
+const (
+	APIKeyPrefix    = "sns_"
+	MaxBatchSize    = 500
+	MaxClockSkew    = 5 * time.Minute  // Readings further in the future are rejected
+	MaxReadingAge   = 24 * time.Hour   // Older readings, e.g. from a gateway offline for days, are rejected
+	OnlineWindow    = 10 * time.Minute // Sensors that reported within this window are shown online
+	DefaultHistory  = 24 * time.Hour
+	MaxHistory      = 7 * 24 * time.Hour
+	DefaultInterval = "5m"
+)
+
+// intervals are the buckets of the telemetry history
+var intervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+}
+
+// Kind is what a sensor measures
+type Kind string
+
+const (
+	KindTemperature Kind = "TEMPERATURE" // Fridge temperature, in °C
+	KindKegLevel    Kind = "KEG_LEVEL"   // Keg fill level, in percent
+)
+
+// IsValid checks if the kind is valid
+func (k Kind) IsValid() bool {
+	return k == KindTemperature || k == KindKegLevel
+}
+
+// Unit returns the unit of the readings of the kind
+func (k Kind) Unit() string {
+	if k == KindKegLevel {
+		return "%"
+	}
+	return "°C"
+}
+
+// Device is a sensor gateway of a stand, e.g. the box in a bar the fridge and keg
+// sensors report through. It authenticates with its API key.
+type Device struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	Name       string     `json:"name" gorm:"not null"`
+	KeyHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	KeyPrefix  string     `json:"keyPrefix"` // Identifies the API key without revealing it
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (Device) TableName() string {
+	return "sensor_devices"
+}
+
+// DeviceWithKey is returned once when a device is registered or its key rotated
+type DeviceWithKey struct {
+	Device
+	APIKey string `json:"apiKey"`
+}
+
+// Sensor is a fridge or keg sensor reporting through a device. Readings outside
+// [MinValue, MaxValue] open an alert; a keg below MinValue also opens a restock task.
+type Sensor struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID    uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID       uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	DeviceID      uuid.UUID  `json:"deviceId" gorm:"type:uuid;not null"`
+	ExternalID    string     `json:"externalId" gorm:"not null"` // Name of the sensor on its device
+	Name          string     `json:"name" gorm:"not null"`
+	Kind          Kind       `json:"kind" gorm:"not null"`
+	ProductID     *uuid.UUID `json:"productId,omitempty" gorm:"type:uuid"` // Product served from the keg
+	MinValue      *float64   `json:"minValue,omitempty"`
+	MaxValue      *float64   `json:"maxValue,omitempty"`
+	Enabled       bool       `json:"enabled" gorm:"not null;default:true"`
+	LastValue     *float64   `json:"lastValue,omitempty"`
+	LastReadingAt *time.Time `json:"lastReadingAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (Sensor) TableName() string {
+	return "sensors"
+}
+
+// Breach returns the alert type of a value outside the thresholds of the sensor, or
+// an empty type
+func (s *Sensor) Breach(value float64) (AlertType, float64) {
+	if s.MaxValue != nil && value > *s.MaxValue {
+		return AlertAboveMax, *s.MaxValue
+	}
+	if s.MinValue != nil && value < *s.MinValue {
+		return AlertBelowMin, *s.MinValue
+	}
+	return "", 0
+}
+
+// SensorResponse is a sensor with its reporting status
+type SensorResponse struct {
+	Sensor
+	Unit   string `json:"unit"`
+	Online bool   `json:"online"`
+}
+
+// Reading is a value reported by a sensor
+type Reading struct {
+	ID         int64     `json:"-" gorm:"primaryKey"`
+	SensorID   uuid.UUID `json:"sensorId" gorm:"type:uuid;not null"`
+	Value      float64   `json:"value" gorm:"not null"`
+	RecordedAt time.Time `json:"recordedAt" gorm:"not null"`
+	ReceivedAt time.Time `json:"receivedAt" gorm:"not null"`
+}
+
+func (Reading) TableName() string {
+	return "sensor_readings"
+}
+
+// AlertType is the threshold a reading crossed
+type AlertType string
+
+const (
+	AlertAboveMax AlertType = "ABOVE_MAX" // E.g. a fridge too warm
+	AlertBelowMin AlertType = "BELOW_MIN" // E.g. a keg nearly empty
+)
+
+// AlertStatus is the state of an alert
+type AlertStatus string
+
+const (
+	AlertOpen     AlertStatus = "OPEN"
+	AlertResolved AlertStatus = "RESOLVED" // Readings back within the thresholds
+)
+
+// Alert is a sensor outside its thresholds, open until its readings are back within
+type Alert struct {
+	ID         uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    uuid.UUID   `json:"standId" gorm:"type:uuid;not null"`
+	SensorID   uuid.UUID   `json:"sensorId" gorm:"type:uuid;not null"`
+	Type       AlertType   `json:"type" gorm:"not null"`
+	Status     AlertStatus `json:"status" gorm:"not null;default:'OPEN'"`
+	Threshold  float64     `json:"threshold"`
+	Value      float64     `json:"value"` // Reading that opened the alert
+	OpenedAt   time.Time   `json:"openedAt"`
+	ResolvedAt *time.Time  `json:"resolvedAt,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+}
+
+func (Alert) TableName() string {
+	return "sensor_alerts"
+}
+
+// TaskStatus is the state of a restock task
+type TaskStatus string
+
+const (
+	TaskOpen TaskStatus = "OPEN"
+	TaskDone TaskStatus = "DONE"
+)
+
+// RestockTask asks the stand staff to replace a keg nearly empty. It is done when the
+// staff completes it or when the keg level is back above its threshold.
+type RestockTask struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID     uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	SensorID    uuid.UUID  `json:"sensorId" gorm:"type:uuid;not null"`
+	ProductID   *uuid.UUID `json:"productId,omitempty" gorm:"type:uuid"`
+	AlertID     uuid.UUID  `json:"alertId" gorm:"type:uuid;not null"`
+	Status      TaskStatus `json:"status" gorm:"not null;default:'OPEN'"`
+	Level       float64    `json:"level"`                                  // Keg level when the task was opened
+	CompletedBy *uuid.UUID `json:"completedBy,omitempty" gorm:"type:uuid"` // Nil when done by a refilled keg
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (RestockTask) TableName() string {
+	return "restock_tasks"
+}
+
+// CreateDeviceRequest represents the request to register a sensor device
+type CreateDeviceRequest struct {
+	StandID uuid.UUID `json:"standId" binding:"required"`
+	Name    string    `json:"name" binding:"required,max=100"`
+}
+
+// CreateSensorRequest represents the request to register a sensor of a device
+type CreateSensorRequest struct {
+	DeviceID   uuid.UUID  `json:"deviceId" binding:"required"`
+	ExternalID string     `json:"externalId" binding:"required,max=100"`
+	Name       string     `json:"name" binding:"required,max=100"`
+	Kind       Kind       `json:"kind" binding:"required"`
+	ProductID  *uuid.UUID `json:"productId"`
+	MinValue   *float64   `json:"minValue"`
+	MaxValue   *float64   `json:"maxValue"`
+}
+
+// UpdateSensorRequest represents the request to update a sensor
+type UpdateSensorRequest struct {
+	Name      *string    `json:"name,omitempty" binding:"omitempty,max=100"`
+	ProductID *uuid.UUID `json:"productId,omitempty"`
+	MinValue  *float64   `json:"minValue,omitempty"`
+	MaxValue  *float64   `json:"maxValue,omitempty"`
+	Enabled   *bool      `json:"enabled,omitempty"`
+}
+
+// IngestRequest is a batch of readings sent by a device
+type IngestRequest struct {
+	Readings []ReadingInput `json:"readings" binding:"required,dive"`
+}
+
+// ReadingInput is a reading of a sensor, identified by its externalId on the device
+type ReadingInput struct {
+	SensorID   string    `json:"sensorId" binding:"required"`
+	Value      float64   `json:"value"`
+	RecordedAt time.Time `json:"recordedAt"` // Time of reception when absent
+}
+
+// IngestResult reports what was stored of a batch
+type IngestResult struct {
+	Accepted       int      `json:"accepted"`
+	Rejected       int      `json:"rejected"`                 // Readings too old, in the future or of disabled sensors
+	UnknownSensors []string `json:"unknownSensors,omitempty"` // externalIds not registered on the device
+}
+
+// HistoryQuery selects the telemetry history of a sensor
+type HistoryQuery struct {
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Interval string    `form:"interval"`
+}
+
+// HistoryPoint aggregates the readings of a sensor over an interval
+type HistoryPoint struct {
+	Time  time.Time `json:"time"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Avg   float64   `json:"avg"`
+	Count int       `json:"count"`
+}
+
+// History is the telemetry history of a sensor
+type History struct {
+	SensorID uuid.UUID      `json:"sensorId"`
+	Unit     string         `json:"unit"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Interval string         `json:"interval"`
+	Points   []HistoryPoint `json:"points"`
+}
+
+// ListFilter filters the listed alerts and restock tasks
+type ListFilter struct {
+	Status  string
+	StandID *uuid.UUID
+	Offset  int
+	Limit   int
+}
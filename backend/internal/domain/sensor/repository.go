@@ -0,0 +1,357 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StandInfo is what sensors need of a stand
+type StandInfo struct {
+	ID         uuid.UUID
+	FestivalID uuid.UUID
+	Name       string
+}
+
+type Repository interface {
+	CreateDevice(ctx context.Context, device *Device) error
+	GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error)
+	GetDeviceByKeyHash(ctx context.Context, keyHash string) (*Device, error)
+	ListDevices(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Device, error)
+	UpdateDevice(ctx context.Context, device *Device) error
+	// DeleteDevice deletes a device with its sensors and their readings
+	DeleteDevice(ctx context.Context, id uuid.UUID) error
+	TouchDevice(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	CreateSensor(ctx context.Context, sensor *Sensor) error
+	GetSensor(ctx context.Context, festivalID, id uuid.UUID) (*Sensor, error)
+	GetSensorByExternalID(ctx context.Context, deviceID uuid.UUID, externalID string) (*Sensor, error)
+	ListSensors(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Sensor, error)
+	ListDeviceSensors(ctx context.Context, deviceID uuid.UUID) ([]Sensor, error)
+	UpdateSensor(ctx context.Context, sensor *Sensor) error
+	DeleteSensor(ctx context.Context, id uuid.UUID) error
+
+	InsertReadings(ctx context.Context, readings []Reading) error
+	// ListHistory aggregates the readings of a sensor in [from, to) per interval
+	ListHistory(ctx context.Context, sensorID uuid.UUID, from, to time.Time, interval time.Duration) ([]HistoryPoint, error)
+
+	GetOpenAlert(ctx context.Context, sensorID uuid.UUID) (*Alert, error)
+	CreateAlert(ctx context.Context, alert *Alert) error
+	UpdateAlert(ctx context.Context, alert *Alert) error
+	ListAlerts(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Alert, int64, error)
+
+	GetOpenTask(ctx context.Context, sensorID uuid.UUID) (*RestockTask, error)
+	GetTask(ctx context.Context, festivalID, id uuid.UUID) (*RestockTask, error)
+	CreateTask(ctx context.Context, task *RestockTask) error
+	UpdateTask(ctx context.Context, task *RestockTask) error
+	ListTasks(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]RestockTask, int64, error)
+
+	GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error)
+	ProductAtStand(ctx context.Context, standID, productID uuid.UUID) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateDevice(ctx context.Context, device *Device) error {
+	if err := r.db.WithContext(ctx).Create(device).Error; err != nil {
+		return fmt.Errorf("failed to create sensor device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error) {
+	var device Device
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&device).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sensor device: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *repository) GetDeviceByKeyHash(ctx context.Context, keyHash string) (*Device, error) {
+	var device Device
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&device).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sensor device: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *repository) ListDevices(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Device, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ?", *standID)
+	}
+
+	var devices []Device
+	if err := query.Order("name").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sensor devices: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *repository) UpdateDevice(ctx context.Context, device *Device) error {
+	if err := r.db.WithContext(ctx).Save(device).Error; err != nil {
+		return fmt.Errorf("failed to update sensor device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteDevice(ctx context.Context, id uuid.UUID) error {
+	// Sensors, readings, alerts and restock tasks cascade
+	if err := r.db.WithContext(ctx).Delete(&Device{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete sensor device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) TouchDevice(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Device{}).Where("id = ?", id).Update("last_seen_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to update sensor device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) CreateSensor(ctx context.Context, sensor *Sensor) error {
+	if err := r.db.WithContext(ctx).Create(sensor).Error; err != nil {
+		return fmt.Errorf("failed to create sensor: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetSensor(ctx context.Context, festivalID, id uuid.UUID) (*Sensor, error) {
+	var sensor Sensor
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&sensor).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sensor: %w", err)
+	}
+	return &sensor, nil
+}
+
+func (r *repository) GetSensorByExternalID(ctx context.Context, deviceID uuid.UUID, externalID string) (*Sensor, error) {
+	var sensor Sensor
+	err := r.db.WithContext(ctx).Where("device_id = ? AND external_id = ?", deviceID, externalID).First(&sensor).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sensor: %w", err)
+	}
+	return &sensor, nil
+}
+
+func (r *repository) ListSensors(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Sensor, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ?", *standID)
+	}
+
+	var sensors []Sensor
+	if err := query.Order("name").Find(&sensors).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sensors: %w", err)
+	}
+	return sensors, nil
+}
+
+func (r *repository) ListDeviceSensors(ctx context.Context, deviceID uuid.UUID) ([]Sensor, error) {
+	var sensors []Sensor
+	if err := r.db.WithContext(ctx).Where("device_id = ?", deviceID).Find(&sensors).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sensors: %w", err)
+	}
+	return sensors, nil
+}
+
+func (r *repository) UpdateSensor(ctx context.Context, sensor *Sensor) error {
+	if err := r.db.WithContext(ctx).Save(sensor).Error; err != nil {
+		return fmt.Errorf("failed to update sensor: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteSensor(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&Sensor{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete sensor: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) InsertReadings(ctx context.Context, readings []Reading) error {
+	if err := r.db.WithContext(ctx).CreateInBatches(readings, 100).Error; err != nil {
+		return fmt.Errorf("failed to insert sensor readings: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListHistory(ctx context.Context, sensorID uuid.UUID, from, to time.Time, interval time.Duration) ([]HistoryPoint, error) {
+	seconds := int64(interval / time.Second)
+
+	var points []HistoryPoint
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT to_timestamp(floor(extract(epoch FROM recorded_at) / ?) * ?) AS time,
+			MIN(value) AS min, MAX(value) AS max, AVG(value) AS avg, COUNT(*) AS count
+		FROM public.sensor_readings
+		WHERE sensor_id = ? AND recorded_at >= ? AND recorded_at < ?
+		GROUP BY 1
+		ORDER BY 1`,
+		seconds, seconds, sensorID, from, to,
+	).Scan(&points).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sensor history: %w", err)
+	}
+	return points, nil
+}
+
+func (r *repository) GetOpenAlert(ctx context.Context, sensorID uuid.UUID) (*Alert, error) {
+	var alert Alert
+	err := r.db.WithContext(ctx).Where("sensor_id = ? AND status = ?", sensorID, AlertOpen).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sensor alert: %w", err)
+	}
+	return &alert, nil
+}
+
+func (r *repository) CreateAlert(ctx context.Context, alert *Alert) error {
+	if err := r.db.WithContext(ctx).Create(alert).Error; err != nil {
+		return fmt.Errorf("failed to create sensor alert: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateAlert(ctx context.Context, alert *Alert) error {
+	if err := r.db.WithContext(ctx).Save(alert).Error; err != nil {
+		return fmt.Errorf("failed to update sensor alert: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListAlerts(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Alert, int64, error) {
+	query := r.filtered(ctx, &Alert{}, festivalID, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count sensor alerts: %w", err)
+	}
+
+	var alerts []Alert
+	if err := query.Order("opened_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&alerts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list sensor alerts: %w", err)
+	}
+	return alerts, total, nil
+}
+
+func (r *repository) GetOpenTask(ctx context.Context, sensorID uuid.UUID) (*RestockTask, error) {
+	var task RestockTask
+	err := r.db.WithContext(ctx).Where("sensor_id = ? AND status = ?", sensorID, TaskOpen).First(&task).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get restock task: %w", err)
+	}
+	return &task, nil
+}
+
+func (r *repository) GetTask(ctx context.Context, festivalID, id uuid.UUID) (*RestockTask, error) {
+	var task RestockTask
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&task).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get restock task: %w", err)
+	}
+	return &task, nil
+}
+
+func (r *repository) CreateTask(ctx context.Context, task *RestockTask) error {
+	if err := r.db.WithContext(ctx).Create(task).Error; err != nil {
+		return fmt.Errorf("failed to create restock task: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateTask(ctx context.Context, task *RestockTask) error {
+	if err := r.db.WithContext(ctx).Save(task).Error; err != nil {
+		return fmt.Errorf("failed to update restock task: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListTasks(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]RestockTask, int64, error) {
+	query := r.filtered(ctx, &RestockTask{}, festivalID, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count restock tasks: %w", err)
+	}
+
+	var tasks []RestockTask
+	if err := query.Order("created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list restock tasks: %w", err)
+	}
+	return tasks, total, nil
+}
+
+// filtered selects the alerts or restock tasks of a festival matching the filter
+func (r *repository) filtered(ctx context.Context, model interface{}, festivalID uuid.UUID, filter ListFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(model).Where("festival_id = ?", festivalID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StandID != nil {
+		query = query.Where("stand_id = ?", *filter.StandID)
+	}
+	return query
+}
+
+func (r *repository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	var infos []StandInfo
+	err := r.db.WithContext(ctx).
+		Table("public.stands").
+		Select("id, festival_id, name").
+		Where("id = ?", standID).
+		Limit(1).
+		Scan(&infos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	return &infos[0], nil
+}
+
+func (r *repository) ProductAtStand(ctx context.Context, standID, productID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("public.products").
+		Where("id = ? AND stand_id = ?", productID, standID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to get product: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,180 @@
+package sensor
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateDevice(ctx context.Context, device *Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetDevice(ctx context.Context, festivalID, id uuid.UUID) (*Device, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Device), args.Error(1)
+}
+
+func (m *MockRepository) GetDeviceByKeyHash(ctx context.Context, keyHash string) (*Device, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Device), args.Error(1)
+}
+
+func (m *MockRepository) ListDevices(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Device, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]Device), args.Error(1)
+}
+
+func (m *MockRepository) UpdateDevice(ctx context.Context, device *Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteDevice(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchDevice(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateSensor(ctx context.Context, sensor *Sensor) error {
+	args := m.Called(ctx, sensor)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetSensor(ctx context.Context, festivalID, id uuid.UUID) (*Sensor, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Sensor), args.Error(1)
+}
+
+func (m *MockRepository) GetSensorByExternalID(ctx context.Context, deviceID uuid.UUID, externalID string) (*Sensor, error) {
+	args := m.Called(ctx, deviceID, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Sensor), args.Error(1)
+}
+
+func (m *MockRepository) ListSensors(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Sensor, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]Sensor), args.Error(1)
+}
+
+func (m *MockRepository) ListDeviceSensors(ctx context.Context, deviceID uuid.UUID) ([]Sensor, error) {
+	args := m.Called(ctx, deviceID)
+	return args.Get(0).([]Sensor), args.Error(1)
+}
+
+func (m *MockRepository) UpdateSensor(ctx context.Context, sensor *Sensor) error {
+	args := m.Called(ctx, sensor)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteSensor(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) InsertReadings(ctx context.Context, readings []Reading) error {
+	args := m.Called(ctx, readings)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListHistory(ctx context.Context, sensorID uuid.UUID, from, to time.Time, interval time.Duration) ([]HistoryPoint, error) {
+	args := m.Called(ctx, sensorID, from, to, interval)
+	return args.Get(0).([]HistoryPoint), args.Error(1)
+}
+
+func (m *MockRepository) GetOpenAlert(ctx context.Context, sensorID uuid.UUID) (*Alert, error) {
+	args := m.Called(ctx, sensorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Alert), args.Error(1)
+}
+
+func (m *MockRepository) CreateAlert(ctx context.Context, alert *Alert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateAlert(ctx context.Context, alert *Alert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListAlerts(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Alert, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Alert), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetOpenTask(ctx context.Context, sensorID uuid.UUID) (*RestockTask, error) {
+	args := m.Called(ctx, sensorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RestockTask), args.Error(1)
+}
+
+func (m *MockRepository) GetTask(ctx context.Context, festivalID, id uuid.UUID) (*RestockTask, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RestockTask), args.Error(1)
+}
+
+func (m *MockRepository) CreateTask(ctx context.Context, task *RestockTask) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateTask(ctx context.Context, task *RestockTask) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListTasks(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]RestockTask, int64, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]RestockTask), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetStandInfo(ctx context.Context, standID uuid.UUID) (*StandInfo, error) {
+	args := m.Called(ctx, standID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandInfo), args.Error(1)
+}
+
+func (m *MockRepository) ProductAtStand(ctx context.Context, standID, productID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, standID, productID)
+	return args.Bool(0), args.Error(1)
+}
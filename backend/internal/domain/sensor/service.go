@@ -0,0 +1,627 @@
+package sensor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/rs/zerolog/log"
+)
+
+// Alerter broadcasts organizer alerts; satisfied by activity.Service
+type Alerter interface {
+	BroadcastAlert(festivalID string, alert *realtime.Alert)
+}
+
+// RestockRequester asks the warehouse for the product of a keg nearly empty;
+// satisfied by restock.Service
+type RestockRequester interface {
+	RequestKeg(ctx context.Context, festivalID, standID, productID uuid.UUID) error
+}
+
+// Service ingests the telemetry of fridge and keg sensors, alerts the stands when a
+// sensor leaves its thresholds and opens restock tasks for kegs nearly empty
+type Service struct {
+	repo      Repository
+	alerter   Alerter
+	requester RestockRequester
+	now       func() time.Time
+}
+
+// NewService creates a new sensor service
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// SetAlerter sets the service used to alert the festival dashboards
+func (s *Service) SetAlerter(alerter Alerter) {
+	s.alerter = alerter
+}
+
+// SetRestockRequester sets the service asking the warehouse for kegs nearly empty
+func (s *Service) SetRestockRequester(requester RestockRequester) {
+	s.requester = requester
+}
+
+// CreateDevice registers a sensor device of a stand and returns its API key, which
+// is only shown once
+func (s *Service) CreateDevice(ctx context.Context, festivalID uuid.UUID, req CreateDeviceRequest) (*DeviceWithKey, error) {
+	stand, err := s.repo.GetStandInfo(ctx, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if stand == nil || stand.FestivalID != festivalID {
+		return nil, ErrStandNotFound
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	device := &Device{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    req.StandID,
+		Name:       req.Name,
+		KeyHash:    hashAPIKey(key),
+		KeyPrefix:  key[:len(APIKeyPrefix)+6],
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateDevice(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return &DeviceWithKey{Device: *device, APIKey: key}, nil
+}
+
+// ListDevices lists the sensor devices of a festival, optionally of one stand
+func (s *Service) ListDevices(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Device, error) {
+	return s.repo.ListDevices(ctx, festivalID, standID)
+}
+
+// RotateAPIKey replaces the API key of a device; the previous key stops working at once
+func (s *Service) RotateAPIKey(ctx context.Context, festivalID, deviceID uuid.UUID) (*DeviceWithKey, error) {
+	device, err := s.getDevice(ctx, festivalID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	device.KeyHash = hashAPIKey(key)
+	device.KeyPrefix = key[:len(APIKeyPrefix)+6]
+	device.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateDevice(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return &DeviceWithKey{Device: *device, APIKey: key}, nil
+}
+
+// DeleteDevice deletes a device with its sensors and their history
+func (s *Service) DeleteDevice(ctx context.Context, festivalID, deviceID uuid.UUID) error {
+	if _, err := s.getDevice(ctx, festivalID, deviceID); err != nil {
+		return err
+	}
+	return s.repo.DeleteDevice(ctx, deviceID)
+}
+
+// CreateSensor registers a sensor of a device
+func (s *Service) CreateSensor(ctx context.Context, festivalID uuid.UUID, req CreateSensorRequest) (*SensorResponse, error) {
+	device, err := s.getDevice(ctx, festivalID, req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Kind.IsValid() {
+		return nil, ErrInvalidKind
+	}
+	if err := validateThresholds(req.MinValue, req.MaxValue); err != nil {
+		return nil, err
+	}
+	if err := s.checkProduct(ctx, device.StandID, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetSensorByExternalID(ctx, device.ID, req.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDuplicateSensor
+	}
+
+	now := s.now()
+	sensor := &Sensor{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    device.StandID,
+		DeviceID:   device.ID,
+		ExternalID: req.ExternalID,
+		Name:       req.Name,
+		Kind:       req.Kind,
+		ProductID:  req.ProductID,
+		MinValue:   req.MinValue,
+		MaxValue:   req.MaxValue,
+		Enabled:    true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateSensor(ctx, sensor); err != nil {
+		return nil, err
+	}
+
+	response := s.toResponse(sensor)
+	return &response, nil
+}
+
+// ListSensors lists the sensors of a festival with their last reading, optionally of
+// one stand
+func (s *Service) ListSensors(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]SensorResponse, error) {
+	sensors, err := s.repo.ListSensors(ctx, festivalID, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]SensorResponse, len(sensors))
+	for i := range sensors {
+		responses[i] = s.toResponse(&sensors[i])
+	}
+	return responses, nil
+}
+
+// UpdateSensor updates the settings of a sensor. New thresholds apply from the next
+// reading.
+func (s *Service) UpdateSensor(ctx context.Context, festivalID, sensorID uuid.UUID, req UpdateSensorRequest) (*SensorResponse, error) {
+	sensor, err := s.getSensor(ctx, festivalID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		sensor.Name = *req.Name
+	}
+	if req.ProductID != nil {
+		if err := s.checkProduct(ctx, sensor.StandID, req.ProductID); err != nil {
+			return nil, err
+		}
+		sensor.ProductID = req.ProductID
+	}
+	if req.MinValue != nil {
+		sensor.MinValue = req.MinValue
+	}
+	if req.MaxValue != nil {
+		sensor.MaxValue = req.MaxValue
+	}
+	if req.Enabled != nil {
+		sensor.Enabled = *req.Enabled
+	}
+	if err := validateThresholds(sensor.MinValue, sensor.MaxValue); err != nil {
+		return nil, err
+	}
+
+	sensor.UpdatedAt = s.now()
+	if err := s.repo.UpdateSensor(ctx, sensor); err != nil {
+		return nil, err
+	}
+
+	response := s.toResponse(sensor)
+	return &response, nil
+}
+
+// DeleteSensor deletes a sensor and its history
+func (s *Service) DeleteSensor(ctx context.Context, festivalID, sensorID uuid.UUID) error {
+	if _, err := s.getSensor(ctx, festivalID, sensorID); err != nil {
+		return err
+	}
+	return s.repo.DeleteSensor(ctx, sensorID)
+}
+
+// AuthenticateDevice resolves the device of an API key
+func (s *Service) AuthenticateDevice(ctx context.Context, key string) (*Device, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	device, err := s.repo.GetDeviceByKeyHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return device, nil
+}
+
+// Ingest stores a batch of readings of a device and evaluates the thresholds of each
+// sensor on its latest reading, so a value crossing a threshold and back within one
+// batch, like a fridge door opened briefly, raises no alert
+func (s *Service) Ingest(ctx context.Context, device *Device, req IngestRequest) (*IngestResult, error) {
+	if len(req.Readings) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(req.Readings) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	sensors, err := s.repo.ListDeviceSensors(ctx, device.ID)
+	if err != nil {
+		return nil, err
+	}
+	byExternalID := make(map[string]*Sensor, len(sensors))
+	for i := range sensors {
+		byExternalID[sensors[i].ExternalID] = &sensors[i]
+	}
+
+	now := s.now()
+	result := &IngestResult{}
+	unknown := make(map[string]bool)
+	latest := make(map[uuid.UUID]Reading)
+	readings := make([]Reading, 0, len(req.Readings))
+	for _, input := range req.Readings {
+		sensor, exists := byExternalID[input.SensorID]
+		if !exists {
+			if !unknown[input.SensorID] {
+				unknown[input.SensorID] = true
+				result.UnknownSensors = append(result.UnknownSensors, input.SensorID)
+			}
+			result.Rejected++
+			continue
+		}
+
+		recordedAt := input.RecordedAt
+		if recordedAt.IsZero() {
+			recordedAt = now
+		}
+		if !sensor.Enabled || recordedAt.After(now.Add(MaxClockSkew)) || recordedAt.Before(now.Add(-MaxReadingAge)) {
+			result.Rejected++
+			continue
+		}
+
+		reading := Reading{
+			SensorID:   sensor.ID,
+			Value:      input.Value,
+			RecordedAt: recordedAt,
+			ReceivedAt: now,
+		}
+		readings = append(readings, reading)
+		if last, exists := latest[sensor.ID]; !exists || !reading.RecordedAt.Before(last.RecordedAt) {
+			latest[sensor.ID] = reading
+		}
+	}
+	result.Accepted = len(readings)
+
+	if len(readings) > 0 {
+		if err := s.repo.InsertReadings(ctx, readings); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range sensors {
+		sensor := &sensors[i]
+		reading, exists := latest[sensor.ID]
+		// Readings synced late from the buffer of a gateway do not override newer ones
+		if !exists || (sensor.LastReadingAt != nil && reading.RecordedAt.Before(*sensor.LastReadingAt)) {
+			continue
+		}
+
+		value := reading.Value
+		sensor.LastValue = &value
+		sensor.LastReadingAt = &reading.RecordedAt
+		sensor.UpdatedAt = now
+		if err := s.repo.UpdateSensor(ctx, sensor); err != nil {
+			return nil, err
+		}
+		if err := s.evaluate(ctx, sensor, reading); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.TouchDevice(ctx, device.ID, now); err != nil {
+		log.Warn().Err(err).Str("device_id", device.ID.String()).Msg("Failed to record sensor device activity")
+	}
+
+	return result, nil
+}
+
+// evaluate opens an alert when a reading leaves the thresholds of its sensor, with a
+// restock task for kegs, and resolves the open alert once readings are back within
+func (s *Service) evaluate(ctx context.Context, sensor *Sensor, reading Reading) error {
+	open, err := s.repo.GetOpenAlert(ctx, sensor.ID)
+	if err != nil {
+		return err
+	}
+
+	breach, threshold := sensor.Breach(reading.Value)
+	now := s.now()
+	switch {
+	case open != nil && open.Type == breach:
+		return nil
+	case open != nil:
+		// Back within the thresholds, or across the other one
+		open.Status = AlertResolved
+		open.ResolvedAt = &now
+		open.UpdatedAt = now
+		if err := s.repo.UpdateAlert(ctx, open); err != nil {
+			return err
+		}
+		if err := s.completeTask(ctx, sensor, nil); err != nil {
+			return err
+		}
+	}
+	if breach == "" {
+		return nil
+	}
+
+	alert := &Alert{
+		ID:         uuid.New(),
+		FestivalID: sensor.FestivalID,
+		StandID:    sensor.StandID,
+		SensorID:   sensor.ID,
+		Type:       breach,
+		Status:     AlertOpen,
+		Threshold:  threshold,
+		Value:      reading.Value,
+		OpenedAt:   reading.RecordedAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateAlert(ctx, alert); err != nil {
+		return err
+	}
+
+	var task *RestockTask
+	if sensor.Kind == KindKegLevel && breach == AlertBelowMin {
+		if task, err = s.openTask(ctx, sensor, alert); err != nil {
+			return err
+		}
+	}
+
+	s.broadcast(sensor, alert, task)
+	return nil
+}
+
+// openTask opens a restock task for a keg nearly empty, unless one is already open
+func (s *Service) openTask(ctx context.Context, sensor *Sensor, alert *Alert) (*RestockTask, error) {
+	existing, err := s.repo.GetOpenTask(ctx, sensor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	task := &RestockTask{
+		ID:         uuid.New(),
+		FestivalID: sensor.FestivalID,
+		StandID:    sensor.StandID,
+		SensorID:   sensor.ID,
+		ProductID:  sensor.ProductID,
+		AlertID:    alert.ID,
+		Status:     TaskOpen,
+		Level:      alert.Value,
+		CreatedAt:  alert.CreatedAt,
+		UpdatedAt:  alert.CreatedAt,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	// The keg is replaced from the stand's reserve; the warehouse refills the reserve
+	if s.requester != nil && sensor.ProductID != nil {
+		if err := s.requester.RequestKeg(ctx, sensor.FestivalID, sensor.StandID, *sensor.ProductID); err != nil {
+			log.Warn().Err(err).Str("sensor_id", sensor.ID.String()).Msg("Failed to request keg restock")
+		}
+	}
+	return task, nil
+}
+
+// completeTask closes the open restock task of a sensor, if any
+func (s *Service) completeTask(ctx context.Context, sensor *Sensor, completedBy *uuid.UUID) error {
+	task, err := s.repo.GetOpenTask(ctx, sensor.ID)
+	if err != nil || task == nil {
+		return err
+	}
+
+	now := s.now()
+	task.Status = TaskDone
+	task.CompletedBy = completedBy
+	task.CompletedAt = &now
+	task.UpdatedAt = now
+	return s.repo.UpdateTask(ctx, task)
+}
+
+// broadcast alerts the festival dashboards about a sensor leaving its thresholds
+func (s *Service) broadcast(sensor *Sensor, alert *Alert, task *RestockTask) {
+	if s.alerter == nil {
+		return
+	}
+
+	var title string
+	switch {
+	case task != nil:
+		title = "Keg nearly empty: " + sensor.Name
+	case sensor.Kind == KindTemperature && alert.Type == AlertAboveMax:
+		title = "Fridge too warm: " + sensor.Name
+	case sensor.Kind == KindTemperature:
+		title = "Fridge too cold: " + sensor.Name
+	default:
+		title = "Sensor out of range: " + sensor.Name
+	}
+
+	actionURL := fmt.Sprintf("/festivals/%s/sensors/%s", sensor.FestivalID, sensor.ID)
+	if task != nil {
+		actionURL = fmt.Sprintf("/festivals/%s/restock-tasks/%s", sensor.FestivalID, task.ID)
+	}
+
+	s.alerter.BroadcastAlert(sensor.FestivalID.String(), &realtime.Alert{
+		ID:        alert.ID.String(),
+		Type:      "warning",
+		Title:     title,
+		Message:   fmt.Sprintf("Reading %.1f%s, threshold %.1f%s", alert.Value, sensor.Kind.Unit(), alert.Threshold, sensor.Kind.Unit()),
+		ActionURL: actionURL,
+	})
+}
+
+// History aggregates the readings of a sensor per interval, over the last day by default
+func (s *Service) History(ctx context.Context, festivalID, sensorID uuid.UUID, query HistoryQuery) (*History, error) {
+	sensor, err := s.getSensor(ctx, festivalID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Interval == "" {
+		query.Interval = DefaultInterval
+	}
+	interval, exists := intervals[query.Interval]
+	if !exists {
+		return nil, ErrInvalidInterval
+	}
+	if query.To.IsZero() {
+		query.To = s.now()
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-DefaultHistory)
+	}
+	if !query.From.Before(query.To) || query.To.Sub(query.From) > MaxHistory {
+		return nil, ErrInvalidRange
+	}
+
+	points, err := s.repo.ListHistory(ctx, sensor.ID, query.From, query.To, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &History{
+		SensorID: sensor.ID,
+		Unit:     sensor.Kind.Unit(),
+		From:     query.From,
+		To:       query.To,
+		Interval: query.Interval,
+		Points:   points,
+	}, nil
+}
+
+// ListAlerts lists the sensor alerts of a festival, latest first
+func (s *Service) ListAlerts(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]Alert, int64, error) {
+	switch AlertStatus(filter.Status) {
+	case "", AlertOpen, AlertResolved:
+	default:
+		return nil, 0, ErrInvalidStatus
+	}
+	return s.repo.ListAlerts(ctx, festivalID, filter)
+}
+
+// ListTasks lists the restock tasks of a festival, latest first
+func (s *Service) ListTasks(ctx context.Context, festivalID uuid.UUID, filter ListFilter) ([]RestockTask, int64, error) {
+	switch TaskStatus(filter.Status) {
+	case "", TaskOpen, TaskDone:
+	default:
+		return nil, 0, ErrInvalidStatus
+	}
+	return s.repo.ListTasks(ctx, festivalID, filter)
+}
+
+// CompleteTask records that the staff replaced a keg
+func (s *Service) CompleteTask(ctx context.Context, festivalID, taskID uuid.UUID, completedBy *uuid.UUID) (*RestockTask, error) {
+	task, err := s.repo.GetTask(ctx, festivalID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+	if task.Status != TaskOpen {
+		return nil, ErrTaskClosed
+	}
+
+	now := s.now()
+	task.Status = TaskDone
+	task.CompletedBy = completedBy
+	task.CompletedAt = &now
+	task.UpdatedAt = now
+	if err := s.repo.UpdateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *Service) getDevice(ctx context.Context, festivalID, deviceID uuid.UUID) (*Device, error) {
+	device, err := s.repo.GetDevice(ctx, festivalID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+func (s *Service) getSensor(ctx context.Context, festivalID, sensorID uuid.UUID) (*Sensor, error) {
+	sensor, err := s.repo.GetSensor(ctx, festivalID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	if sensor == nil {
+		return nil, ErrSensorNotFound
+	}
+	return sensor, nil
+}
+
+// checkProduct checks that the product of a keg is sold at the stand of its sensor
+func (s *Service) checkProduct(ctx context.Context, standID uuid.UUID, productID *uuid.UUID) error {
+	if productID == nil {
+		return nil
+	}
+	exists, err := s.repo.ProductAtStand(ctx, standID, *productID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+func (s *Service) toResponse(sensor *Sensor) SensorResponse {
+	return SensorResponse{
+		Sensor: *sensor,
+		Unit:   sensor.Kind.Unit(),
+		Online: sensor.LastReadingAt != nil && s.now().Sub(*sensor.LastReadingAt) < OnlineWindow,
+	}
+}
+
+func validateThresholds(minValue, maxValue *float64) error {
+	if minValue != nil && maxValue != nil && *minValue >= *maxValue {
+		return ErrInvalidThresholds
+	}
+	return nil
+}
+
+func generateAPIKey() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate sensor API key: %w", err)
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,255 @@
+package sensor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAlerter struct {
+	alerts []*realtime.Alert
+}
+
+func (a *fakeAlerter) BroadcastAlert(festivalID string, alert *realtime.Alert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func newTestService(repo Repository, now time.Time) (*Service, *fakeAlerter) {
+	alerter := &fakeAlerter{}
+	service := NewService(repo)
+	service.SetAlerter(alerter)
+	service.now = func() time.Time { return now }
+	return service, alerter
+}
+
+// testDevice returns a device of a bar with its fridge, keg and disabled keg sensors
+func testDevice() (*Device, []Sensor) {
+	festivalID, standID := uuid.New(), uuid.New()
+	device := &Device{ID: uuid.New(), FestivalID: festivalID, StandID: standID, Name: "Main bar", KeyHash: hashAPIKey("sns_test")}
+	productID := uuid.New()
+	sensors := []Sensor{
+		{ID: uuid.New(), FestivalID: festivalID, StandID: standID, DeviceID: device.ID, ExternalID: "fridge-1", Name: "Fridge 1", Kind: KindTemperature, MinValue: float(1), MaxValue: float(6), Enabled: true},
+		{ID: uuid.New(), FestivalID: festivalID, StandID: standID, DeviceID: device.ID, ExternalID: "keg-1", Name: "Keg 1", Kind: KindKegLevel, ProductID: &productID, MinValue: float(10), Enabled: true},
+		{ID: uuid.New(), FestivalID: festivalID, StandID: standID, DeviceID: device.ID, ExternalID: "keg-2", Name: "Keg 2", Kind: KindKegLevel, MinValue: float(10)},
+	}
+	return device, sensors
+}
+
+// expectIngest serves the sensors of the device to every batch. The service updates the
+// returned sensors in place, so the latest reading of each is kept across batches.
+func expectIngest(mockRepo *MockRepository, device *Device, sensors []Sensor) {
+	mockRepo.On("ListDeviceSensors", mock.Anything, device.ID).Return(sensors, nil)
+	mockRepo.On("InsertReadings", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateSensor", mock.Anything, mock.AnythingOfType("*sensor.Sensor")).Return(nil)
+	mockRepo.On("TouchDevice", mock.Anything, device.ID, mock.Anything).Return(nil)
+}
+
+// expectCreated records the alerts and restock tasks opened while ingesting
+func expectCreated(mockRepo *MockRepository) (*[]*Alert, *[]*RestockTask) {
+	alerts := &[]*Alert{}
+	tasks := &[]*RestockTask{}
+	mockRepo.On("CreateAlert", mock.Anything, mock.AnythingOfType("*sensor.Alert")).
+		Run(func(args mock.Arguments) { *alerts = append(*alerts, args.Get(1).(*Alert)) }).
+		Return(nil).Maybe()
+	mockRepo.On("CreateTask", mock.Anything, mock.AnythingOfType("*sensor.RestockTask")).
+		Run(func(args mock.Arguments) { *tasks = append(*tasks, args.Get(1).(*RestockTask)) }).
+		Return(nil).Maybe()
+	return alerts, tasks
+}
+
+func TestIngest_StoresBatchAndRejectsInvalidReadings(t *testing.T) {
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo, now)
+	device, sensors := testDevice()
+	fridge, keg := &sensors[0], &sensors[1]
+
+	mockRepo.On("GetDeviceByKeyHash", mock.Anything, hashAPIKey("sns_test")).Return(device, nil)
+	mockRepo.On("GetDeviceByKeyHash", mock.Anything, hashAPIKey("sns_other")).Return(nil, nil)
+	authenticated, err := service.AuthenticateDevice(context.Background(), "sns_test")
+	require.NoError(t, err)
+	assert.Equal(t, device.ID, authenticated.ID)
+	_, err = service.AuthenticateDevice(context.Background(), "sns_other")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	mockRepo.On("ListDeviceSensors", mock.Anything, device.ID).Return(sensors, nil)
+	mockRepo.On("InsertReadings", mock.Anything, mock.MatchedBy(func(readings []Reading) bool {
+		return len(readings) == 3
+	})).Return(nil).Once()
+	mockRepo.On("UpdateSensor", mock.Anything, fridge).Return(nil).Once()
+	mockRepo.On("UpdateSensor", mock.Anything, keg).Return(nil).Once()
+	mockRepo.On("GetOpenAlert", mock.Anything, fridge.ID).Return(nil, nil).Once()
+	mockRepo.On("GetOpenAlert", mock.Anything, keg.ID).Return(nil, nil).Once()
+	mockRepo.On("TouchDevice", mock.Anything, device.ID, now).Return(nil)
+
+	result, err := service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "fridge-1", Value: 4.2, RecordedAt: now.Add(-2 * time.Minute)},
+		{SensorID: "fridge-1", Value: 4.5, RecordedAt: now.Add(-time.Minute)},
+		{SensorID: "fridge-1", Value: 4.1, RecordedAt: now.Add(-48 * time.Hour)}, // Too old
+		{SensorID: "fridge-1", Value: 4.1, RecordedAt: now.Add(time.Hour)},       // In the future
+		{SensorID: "keg-1", Value: 80},                                           // Received now
+		{SensorID: "keg-2", Value: 50},                                           // Disabled
+		{SensorID: "fridge-9", Value: 3},
+		{SensorID: "fridge-9", Value: 3},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Accepted)
+	assert.Equal(t, 5, result.Rejected)
+	assert.Equal(t, []string{"fridge-9"}, result.UnknownSensors)
+
+	require.NotNil(t, fridge.LastValue)
+	assert.Equal(t, 4.5, *fridge.LastValue)
+	assert.Equal(t, now.Add(-time.Minute), *fridge.LastReadingAt)
+	assert.Equal(t, 80.0, *keg.LastValue)
+
+	// A reading buffered by the gateway does not override the latest one
+	mockRepo.On("InsertReadings", mock.Anything, mock.MatchedBy(func(readings []Reading) bool {
+		return len(readings) == 1
+	})).Return(nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "fridge-1", Value: 9, RecordedAt: now.Add(-10 * time.Minute)},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 4.5, *fridge.LastValue)
+
+	_, err = service.Ingest(context.Background(), device, IngestRequest{})
+	assert.ErrorIs(t, err, ErrEmptyBatch)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateAlert", mock.Anything, mock.Anything)
+}
+
+func TestIngest_AlertsWhenFridgeTooWarm(t *testing.T) {
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	mockRepo := NewMockRepository()
+	service, alerter := newTestService(mockRepo, now)
+	device, sensors := testDevice()
+	fridge := &sensors[0]
+	expectIngest(mockRepo, device, sensors)
+	alerts, _ := expectCreated(mockRepo)
+
+	// Door opened briefly within a batch
+	mockRepo.On("GetOpenAlert", mock.Anything, fridge.ID).Return(nil, nil).Once()
+	_, err := service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "fridge-1", Value: 8, RecordedAt: now.Add(-2 * time.Minute)},
+		{SensorID: "fridge-1", Value: 5, RecordedAt: now.Add(-time.Minute)},
+	}})
+	require.NoError(t, err)
+	assert.Empty(t, *alerts)
+
+	mockRepo.On("GetOpenAlert", mock.Anything, fridge.ID).Return(nil, nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "fridge-1", Value: 8.5, RecordedAt: now},
+	}})
+	require.NoError(t, err)
+	require.Len(t, *alerts, 1)
+	alert := (*alerts)[0]
+	assert.Equal(t, AlertAboveMax, alert.Type)
+	assert.Equal(t, 6.0, alert.Threshold)
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "Fridge too warm: Fridge 1", alerter.alerts[0].Title)
+
+	// Still too warm: the alert stays open without another broadcast
+	service.now = func() time.Time { return now.Add(time.Minute) }
+	mockRepo.On("GetOpenAlert", mock.Anything, fridge.ID).Return(alert, nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "fridge-1", Value: 9},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, *alerts, 1)
+	assert.Len(t, alerter.alerts, 1)
+
+	service.now = func() time.Time { return now.Add(2 * time.Minute) }
+	mockRepo.On("GetOpenAlert", mock.Anything, fridge.ID).Return(alert, nil).Once()
+	mockRepo.On("UpdateAlert", mock.Anything, alert).Return(nil).Once()
+	mockRepo.On("GetOpenTask", mock.Anything, fridge.ID).Return(nil, nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "fridge-1", Value: 5},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, AlertResolved, alert.Status)
+	require.NotNil(t, alert.ResolvedAt)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+}
+
+func TestIngest_OpensRestockTaskForLowKeg(t *testing.T) {
+	now := time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+	mockRepo := NewMockRepository()
+	service, alerter := newTestService(mockRepo, now)
+	device, sensors := testDevice()
+	keg := &sensors[1]
+	expectIngest(mockRepo, device, sensors)
+	alerts, tasks := expectCreated(mockRepo)
+
+	mockRepo.On("GetOpenAlert", mock.Anything, keg.ID).Return(nil, nil).Once()
+	mockRepo.On("GetOpenTask", mock.Anything, keg.ID).Return(nil, nil).Once()
+	_, err := service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "keg-1", Value: 8},
+	}})
+	require.NoError(t, err)
+	require.Len(t, *tasks, 1)
+	task := (*tasks)[0]
+	assert.Equal(t, TaskOpen, task.Status)
+	assert.Equal(t, keg.ProductID, task.ProductID)
+	assert.Equal(t, 8.0, task.Level)
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "Keg nearly empty: Keg 1", alerter.alerts[0].Title)
+
+	staffID := uuid.New()
+	mockRepo.On("GetTask", mock.Anything, keg.FestivalID, task.ID).Return(task, nil).Twice()
+	mockRepo.On("UpdateTask", mock.Anything, task).Return(nil).Once()
+	done, err := service.CompleteTask(context.Background(), keg.FestivalID, task.ID, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, TaskDone, done.Status)
+	assert.Equal(t, &staffID, done.CompletedBy)
+	_, err = service.CompleteTask(context.Background(), keg.FestivalID, task.ID, &staffID)
+	assert.ErrorIs(t, err, ErrTaskClosed)
+
+	// A new keg resolves the alert; the next empty one opens a new task
+	service.now = func() time.Time { return now.Add(time.Minute) }
+	mockRepo.On("GetOpenAlert", mock.Anything, keg.ID).Return((*alerts)[0], nil).Once()
+	mockRepo.On("UpdateAlert", mock.Anything, (*alerts)[0]).Return(nil).Once()
+	mockRepo.On("GetOpenTask", mock.Anything, keg.ID).Return(nil, nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "keg-1", Value: 100},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, AlertResolved, (*alerts)[0].Status)
+
+	service.now = func() time.Time { return now.Add(time.Hour) }
+	mockRepo.On("GetOpenAlert", mock.Anything, keg.ID).Return(nil, nil).Once()
+	mockRepo.On("GetOpenTask", mock.Anything, keg.ID).Return(nil, nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "keg-1", Value: 5},
+	}})
+	require.NoError(t, err)
+	require.Len(t, *tasks, 2)
+	refilled := (*tasks)[1]
+
+	// Refilled before the staff completed the task
+	service.now = func() time.Time { return now.Add(2 * time.Hour) }
+	mockRepo.On("GetOpenAlert", mock.Anything, keg.ID).Return((*alerts)[1], nil).Once()
+	mockRepo.On("UpdateAlert", mock.Anything, (*alerts)[1]).Return(nil).Once()
+	mockRepo.On("GetOpenTask", mock.Anything, keg.ID).Return(refilled, nil).Once()
+	mockRepo.On("UpdateTask", mock.Anything, refilled).Return(nil).Once()
+	_, err = service.Ingest(context.Background(), device, IngestRequest{Readings: []ReadingInput{
+		{SensorID: "keg-1", Value: 100},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, TaskDone, refilled.Status)
+	assert.Nil(t, refilled.CompletedBy)
+
+	mockRepo.AssertExpectations(t)
+}
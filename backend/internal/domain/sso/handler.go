@@ -0,0 +1,305 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the connection management routes, for administrators
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	conns := r.Group("/sso-connections")
+	{
+		conns.GET("", h.ListConnections)
+		conns.POST("", h.CreateConnection)
+		conns.GET("/:connectionId", h.GetConnection)
+		conns.PATCH("/:connectionId", h.UpdateConnection)
+		conns.DELETE("/:connectionId", h.DeleteConnection)
+	}
+}
+
+// RegisterLoginRoutes registers the public sign-in routes
+func (h *Handler) RegisterLoginRoutes(r *gin.RouterGroup) {
+	r.GET("/discover", h.Discover)
+	r.GET("/callback", h.Callback)
+	r.GET("/:slug/login", h.Login)
+}
+
+// ListConnections lists the SSO connections
+// @Summary List SSO connections
+// @Description List the OpenID Connect connections of the organizations. Client secrets are never returned.
+// @Tags sso
+// @Produce json
+// @Success 200 {object} response.Response{data=[]Connection} "Connections"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/sso-connections [get]
+func (h *Handler) ListConnections(c *gin.Context) {
+	conns, err := h.service.ListConnections(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, conns)
+}
+
+// CreateConnection creates an SSO connection
+// @Summary Create SSO connection
+// @Description Create the OpenID Connect connection of an organization. Register the callback URL of the API at the provider. Groups map to the ORGANIZER, STAFF or USER roles; without a matching group the default role applies, and sign-in is refused when there is none.
+// @Tags sso
+// @Accept json
+// @Produce json
+// @Param request body CreateConnectionRequest true "Connection"
+// @Success 201 {object} response.Response{data=Connection} "Connection created"
+// @Failure 400 {object} response.ErrorResponse "Invalid connection or role mapping"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 409 {object} response.ErrorResponse "Slug or email domain already in use"
+// @Security BearerAuth
+// @Router /admin/sso-connections [post]
+func (h *Handler) CreateConnection(c *gin.Context) {
+	var req CreateConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		createdBy = &userID
+	}
+
+	conn, err := h.service.CreateConnection(c.Request.Context(), req, createdBy)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, conn)
+}
+
+// GetConnection returns an SSO connection
+// @Summary Get SSO connection
+// @Tags sso
+// @Produce json
+// @Param connectionId path string true "Connection ID" format(uuid)
+// @Success 200 {object} response.Response{data=Connection} "Connection"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Connection not found"
+// @Security BearerAuth
+// @Router /admin/sso-connections/{connectionId} [get]
+func (h *Handler) GetConnection(c *gin.Context) {
+	id, ok := connectionID(c)
+	if !ok {
+		return
+	}
+
+	conn, err := h.service.GetConnection(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, conn)
+}
+
+// UpdateConnection updates an SSO connection
+// @Summary Update SSO connection
+// @Description Update a connection. The client secret is only replaced when given. Users get their new mapped role on their next sign-in.
+// @Tags sso
+// @Accept json
+// @Produce json
+// @Param connectionId path string true "Connection ID" format(uuid)
+// @Param request body UpdateConnectionRequest true "Changes"
+// @Success 200 {object} response.Response{data=Connection} "Connection updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid connection or role mapping"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Connection not found"
+// @Failure 409 {object} response.ErrorResponse "Email domain already in use"
+// @Security BearerAuth
+// @Router /admin/sso-connections/{connectionId} [patch]
+func (h *Handler) UpdateConnection(c *gin.Context) {
+	id, ok := connectionID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	conn, err := h.service.UpdateConnection(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, conn)
+}
+
+// DeleteConnection deletes an SSO connection
+// @Summary Delete SSO connection
+// @Description Delete a connection. The users it provisioned are kept.
+// @Tags sso
+// @Param connectionId path string true "Connection ID" format(uuid)
+// @Success 204 "Connection deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Connection not found"
+// @Security BearerAuth
+// @Router /admin/sso-connections/{connectionId} [delete]
+func (h *Handler) DeleteConnection(c *gin.Context) {
+	id, ok := connectionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteConnection(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Discover returns the SSO connection of an email
+// @Summary Discover SSO connection
+// @Description Find the connection the owner of an email signs in with, from its domain
+// @Tags sso
+// @Produce json
+// @Param email query string true "Email"
+// @Success 200 {object} response.Response{data=Discovery} "Connection"
+// @Failure 404 {object} response.ErrorResponse "No connection for the domain"
+// @Router /auth/sso/discover [get]
+func (h *Handler) Discover(c *gin.Context) {
+	discovery, err := h.service.Discover(c.Request.Context(), c.Query("email"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, discovery)
+}
+
+// Login starts a sign-in with an SSO connection
+// @Summary Start SSO sign-in
+// @Description Redirect to the identity provider of the connection. Once signed in, the user is sent back to the redirect URL, which must be on an allowed origin.
+// @Tags sso
+// @Param slug path string true "Connection slug"
+// @Param redirect query string true "Frontend URL to return to"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 400 {object} response.ErrorResponse "Redirect not allowed"
+// @Failure 404 {object} response.ErrorResponse "Connection not found"
+// @Failure 502 {object} response.ErrorResponse "Identity provider unreachable"
+// @Router /auth/sso/{slug}/login [get]
+func (h *Handler) Login(c *gin.Context) {
+	authURL, err := h.service.StartLogin(c.Request.Context(), c.Param("slug"), c.Query("redirect"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes a sign-in with an SSO connection
+// @Summary Complete SSO sign-in
+// @Description Redirect URI registered at the identity providers. The user is sent back to the redirect URL of the sign-in with the session token and its expiry in the fragment: #token=...&expires_at=...
+// @Tags sso
+// @Param state query string true "State of the sign-in"
+// @Param code query string true "Authorization code"
+// @Success 302 "Redirect to the frontend"
+// @Failure 400 {object} response.ErrorResponse "Sign-in expired or refused by the provider"
+// @Failure 403 {object} response.ErrorResponse "Email outside the domains of the connection, no mapped role or banned user"
+// @Failure 502 {object} response.ErrorResponse "Identity provider unreachable or invalid ID token"
+// @Router /auth/sso/callback [get]
+func (h *Handler) Callback(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	if providerError := c.Query("error"); providerError != "" {
+		response.BadRequest(c, "PROVIDER_ERROR", "Identity provider refused the sign-in", gin.H{
+			"error":       providerError,
+			"description": c.Query("error_description"),
+		})
+		return
+	}
+	if c.Query("state") == "" || c.Query("code") == "" {
+		response.BadRequest(c, "VALIDATION_ERROR", "state and code are required", nil)
+		return
+	}
+
+	session, err := h.service.CompleteLogin(c.Request.Context(), c.Query("state"), c.Query("code"), RequestInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// The token travels in the fragment so it never reaches the logs of the frontend host
+	fragment := url.Values{
+		"token":      {session.Token},
+		"expires_at": {strconv.FormatInt(session.ExpiresAt.Unix(), 10)},
+	}
+	c.Redirect(http.StatusFound, session.Redirect+"#"+fragment.Encode())
+}
+
+func connectionID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("connectionId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid connection ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrConnectionNotFound):
+		response.NotFound(c, "SSO connection not found")
+	case errors.Is(err, ErrNoConnection):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrConnectionDisabled):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrSlugTaken):
+		response.Conflict(c, "SLUG_TAKEN", err.Error())
+	case errors.Is(err, ErrDomainTaken):
+		response.Conflict(c, "DOMAIN_TAKEN", err.Error())
+	case errors.Is(err, ErrInvalidConnection):
+		response.BadRequest(c, "INVALID_CONNECTION", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRoleMapping):
+		response.BadRequest(c, "INVALID_ROLE_MAPPING", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRedirect):
+		response.BadRequest(c, "INVALID_REDIRECT", err.Error(), nil)
+	case errors.Is(err, ErrInvalidState):
+		response.BadRequest(c, "INVALID_STATE", err.Error(), nil)
+	case errors.Is(err, ErrEmailDomainMismatch), errors.Is(err, ErrNoMappedRole), errors.Is(err, user.ErrUserBanned):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrProviderError), errors.Is(err, ErrInvalidIDToken):
+		c.JSON(http.StatusBadGateway, response.ErrorResponse{
+			Error: response.ErrorDetail{Code: "PROVIDER_ERROR", Message: err.Error()},
+		})
+	case errors.Is(err, ErrSecretsUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,192 @@
+package sso
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+)
+
+// SSO errors
+var (
+	ErrConnectionNotFound  = errors.New("SSO connection not found")
+	ErrConnectionDisabled  = errors.New("SSO connection is disabled")
+	ErrNoConnection        = errors.New("no SSO connection for this email domain")
+	ErrSlugTaken           = errors.New("SSO connection slug already in use")
+	ErrDomainTaken         = errors.New("email domain already belongs to another SSO connection")
+	ErrInvalidConnection   = errors.New("SSO connection needs a slug of lowercase letters, digits and dashes, an https issuer and at least one email domain")
+	ErrInvalidRoleMapping  = errors.New("groups can only map to the ORGANIZER, STAFF or USER roles")
+	ErrSecretsUnavailable  = errors.New("client secrets cannot be stored: no SSO encryption key is configured")
+	ErrInvalidRedirect     = errors.New("redirect is not on an allowed origin")
+	ErrInvalidState        = errors.New("sign-in expired or was already completed")
+	ErrProviderError       = errors.New("identity provider request failed")
+	ErrInvalidIDToken      = errors.New("invalid ID token")
+	ErrEmailDomainMismatch = errors.New("email of the identity provider is outside the domains of the connection")
+	ErrNoMappedRole        = errors.New("none of the groups of the user grants access")
+)
+
+// Sign-in flow
+const (
+	DefaultGroupsClaim = "groups"
+	LoginStateTTL      = 10 * time.Minute
+	SessionTTL         = 8 * time.Hour
+	ProviderCacheTTL   = time.Hour // Discovery documents and signing keys of the providers
+	SubjectPrefix      = "oidc|"
+)
+
+// RoleMapping grants a role to the members of an IdP group
+type RoleMapping struct {
+	Group string        `json:"group"` // Value of the groups claim: a name, or an object ID with Azure AD
+	Role  user.UserRole `json:"role"`
+}
+
+// Connection is the OpenID Connect provider of an organization, such as its Azure AD
+// tenant or Google Workspace. Its members sign in with it and get the role the groups
+// they belong to map to.
+type Connection struct {
+	ID           uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name         string        `json:"name" gorm:"not null"`                                    // Organization shown on the login page
+	Slug         string        `json:"slug" gorm:"not null;uniqueIndex"`                        // Identifies the connection in its login URL
+	EmailDomains []string      `json:"emailDomains" gorm:"type:jsonb;serializer:json;not null"` // Home realm discovery; emails outside are rejected
+	Issuer       string        `json:"issuer" gorm:"not null"`
+	ClientID     string        `json:"clientId" gorm:"not null"`
+	ClientSecret string        `json:"-" gorm:"column:client_secret_encrypted;not null"`
+	Scopes       []string      `json:"scopes" gorm:"type:jsonb;serializer:json;not null"` // Requested with openid, email and profile
+	GroupsClaim  string        `json:"groupsClaim" gorm:"not null;default:'groups'"`
+	RoleMappings []RoleMapping `json:"roleMappings" gorm:"type:jsonb;serializer:json;not null"`
+	DefaultRole  user.UserRole `json:"defaultRole,omitempty"`                                  // Role when no group maps; sign-in is refused when empty
+	FestivalIDs  []uuid.UUID   `json:"festivalIds" gorm:"type:jsonb;serializer:json;not null"` // Festivals of the organization, managed by its organizers
+	Enabled      bool          `json:"enabled" gorm:"not null;default:true"`
+	CreatedBy    *uuid.UUID    `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+func (Connection) TableName() string {
+	return "sso_connections"
+}
+
+// HasDomain reports whether email belongs to one of the domains of the connection
+func (c *Connection) HasDomain(email string) bool {
+	domain := emailDomain(email)
+	for _, d := range c.EmailDomains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// MapRole returns the role with the most access among those the groups map to, or the
+// default role when none does. It reports false when the user gets no role.
+func (c *Connection) MapRole(groups []string) (user.UserRole, bool) {
+	member := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		member[g] = true
+	}
+
+	var role user.UserRole
+	for _, m := range c.RoleMappings {
+		if member[m.Group] && (role == "" || roleRank(m.Role) > roleRank(role)) {
+			role = m.Role
+		}
+	}
+	if role == "" {
+		role = c.DefaultRole
+	}
+	return role, role != ""
+}
+
+// LoginState is a sign-in started with a connection, completed once by the callback
+type LoginState struct {
+	ID           string    `json:"id" gorm:"primary_key"` // The state parameter
+	ConnectionID uuid.UUID `json:"connectionId" gorm:"type:uuid;not null"`
+	Nonce        string    `json:"-" gorm:"not null"`
+	CodeVerifier string    `json:"-" gorm:"not null"` // PKCE
+	Redirect     string    `json:"redirect" gorm:"not null"`
+	ExpiresAt    time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (LoginState) TableName() string {
+	return "sso_login_states"
+}
+
+// CreateConnectionRequest represents the request to create a connection
+type CreateConnectionRequest struct {
+	Name         string        `json:"name" binding:"required"`
+	Slug         string        `json:"slug" binding:"required"`
+	EmailDomains []string      `json:"emailDomains" binding:"required,min=1"`
+	Issuer       string        `json:"issuer" binding:"required"`
+	ClientID     string        `json:"clientId" binding:"required"`
+	ClientSecret string        `json:"clientSecret" binding:"required"`
+	Scopes       []string      `json:"scopes"`
+	GroupsClaim  string        `json:"groupsClaim"`
+	RoleMappings []RoleMapping `json:"roleMappings"`
+	DefaultRole  user.UserRole `json:"defaultRole"`
+	FestivalIDs  []uuid.UUID   `json:"festivalIds"`
+}
+
+// UpdateConnectionRequest represents the request to update a connection. The client
+// secret is only replaced when given.
+type UpdateConnectionRequest struct {
+	Name         *string        `json:"name"`
+	EmailDomains []string       `json:"emailDomains"`
+	Issuer       *string        `json:"issuer"`
+	ClientID     *string        `json:"clientId"`
+	ClientSecret *string        `json:"clientSecret"`
+	Scopes       []string       `json:"scopes"`
+	GroupsClaim  *string        `json:"groupsClaim"`
+	RoleMappings []RoleMapping  `json:"roleMappings"`
+	DefaultRole  *user.UserRole `json:"defaultRole"`
+	FestivalIDs  []uuid.UUID    `json:"festivalIds"`
+	Enabled      *bool          `json:"enabled"`
+}
+
+// Discovery is the connection to sign in with for an email
+type Discovery struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	LoginURL string `json:"loginUrl"`
+}
+
+// Session is the outcome of a completed sign-in
+type Session struct {
+	Token     string    `json:"token"` // Bearer token accepted next to Auth0 tokens
+	ExpiresAt time.Time `json:"expiresAt"`
+	Redirect  string    `json:"redirect"`
+	User      user.User `json:"user"`
+}
+
+// RequestInfo identifies the client of a sign-in in the audit log
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// roleRank orders the mappable roles by the access they grant
+func roleRank(role user.UserRole) int {
+	switch role {
+	case user.UserRoleOrganizer:
+		return 2
+	case user.UserRoleStaff:
+		return 1
+	}
+	return 0
+}
+
+// mappable reports whether a role can be granted through an SSO connection. Platform
+// administrators are never provisioned by an organization.
+func mappable(role user.UserRole) bool {
+	return role == user.UserRoleOrganizer || role == user.UserRoleStaff || role == user.UserRoleUser
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
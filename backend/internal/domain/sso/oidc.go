@@ -0,0 +1,266 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// providerMinRefreshInterval limits how often ID tokens signed with an unknown key can
+// make the signing keys of a provider be fetched again
+const providerMinRefreshInterval = time.Minute
+
+// idTokenMethods are the signing algorithms accepted for ID tokens
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}
+
+// providerMetadata is the part of the discovery document of a provider the sign-in uses
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// provider is the discovery document and signing keys of an issuer
+type provider struct {
+	metadata  providerMetadata
+	keys      jwk.Set
+	fetchedAt time.Time
+}
+
+// tokenResponse is the response of the token endpoint to the authorization code grant
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// identity is what the ID token of a sign-in says about the user
+type identity struct {
+	Subject  string
+	Email    string
+	Name     string
+	Groups   []string
+	AMR      []string
+	AuthTime int64
+}
+
+// provider returns the cached discovery document and keys of an issuer, fetched again
+// once stale or, with refresh, when a token is signed with an unknown key
+func (s *Service) provider(ctx context.Context, issuer string, refresh bool) (*provider, error) {
+	s.mu.Lock()
+	cached, ok := s.providers[issuer]
+	s.mu.Unlock()
+	if ok {
+		age := s.now().Sub(cached.fetchedAt)
+		if age < providerMinRefreshInterval || (!refresh && age < ProviderCacheTTL) {
+			return cached, nil
+		}
+	}
+
+	fetched, err := s.fetchProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.providers[issuer] = fetched
+	s.mu.Unlock()
+	return fetched, nil
+}
+
+func (s *Service) fetchProvider(ctx context.Context, issuer string) (*provider, error) {
+	var metadata providerMetadata
+	if err := s.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Issuer != issuer {
+		return nil, fmt.Errorf("%w: discovery document is for issuer %q", ErrProviderError, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProviderError)
+	}
+
+	keys, err := jwk.Fetch(ctx, metadata.JWKSURI, jwk.WithHTTPClient(s.httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch signing keys: %v", ErrProviderError, err)
+	}
+	return &provider{metadata: metadata, keys: keys, fetchedAt: s.now()}, nil
+}
+
+func (s *Service) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderError, err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderError, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrProviderError, endpoint, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid response of %s: %v", ErrProviderError, endpoint, err)
+	}
+	return nil
+}
+
+// authorizationURL returns the URL of the provider the user signs in at, for the
+// authorization code flow with PKCE
+func (s *Service) authorizationURL(p *provider, conn *Connection, state *LoginState) (string, error) {
+	u, err := url.Parse(p.metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid authorization endpoint: %v", ErrProviderError, err)
+	}
+	challenge := sha256.Sum256([]byte(state.CodeVerifier))
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", conn.ClientID)
+	q.Set("redirect_uri", s.config.CallbackURL)
+	q.Set("scope", strings.Join(append([]string{"openid", "email", "profile"}, conn.Scopes...), " "))
+	q.Set("state", state.ID)
+	q.Set("nonce", state.Nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// exchangeCode redeems the authorization code of a sign-in for its ID token
+func (s *Service) exchangeCode(ctx context.Context, p *provider, conn *Connection, clientSecret, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.config.CallbackURL},
+		"client_id":     {conn.ClientID},
+		"client_secret": {clientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProviderError, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProviderError, err)
+	}
+	defer resp.Body.Close()
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("%w: invalid token response: %v", ErrProviderError, err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return "", fmt.Errorf("%w: token endpoint returned %d %s %s", ErrProviderError, resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no ID token", ErrProviderError)
+	}
+	return tokens.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+// and returns the identity it asserts
+func (s *Service) verifyIDToken(ctx context.Context, p *provider, conn *Connection, rawIDToken, nonce string) (*identity, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(idTokenMethods),
+		jwt.WithIssuer(conn.Issuer),
+		jwt.WithAudience(conn.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+		jwt.WithTimeFunc(s.now),
+	)
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := p.keys.LookupKeyID(kid)
+		if !ok {
+			refreshed, err := s.provider(ctx, conn.Issuer, true)
+			if err != nil {
+				return nil, err
+			}
+			if key, ok = refreshed.keys.LookupKeyID(kid); !ok {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+		}
+		var raw interface{}
+		if err := key.Raw(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	tokenNonce, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != conn.ClientID {
+		return nil, fmt.Errorf("%w: issued to another client", ErrInvalidIDToken)
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return nil, fmt.Errorf("%w: email is not verified", ErrInvalidIDToken)
+	}
+
+	id := &identity{
+		Groups: stringsClaim(claims[conn.GroupsClaim]),
+		AMR:    stringsClaim(claims["amr"]),
+	}
+	id.Subject, _ = claims["sub"].(string)
+	id.Name, _ = claims["name"].(string)
+	id.Email, _ = claims["email"].(string)
+	if id.Email == "" {
+		// Azure AD only has the email claim for accounts with a mailbox
+		if username, _ := claims["preferred_username"].(string); strings.Contains(username, "@") {
+			id.Email = username
+		}
+	}
+	id.Email = strings.ToLower(id.Email)
+	// The sign-in happened when the token was issued unless the provider says otherwise
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		id.AuthTime = int64(authTime)
+	} else if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		id.AuthTime = issuedAt.Unix()
+	}
+	if id.Subject == "" || id.Email == "" {
+		return nil, fmt.Errorf("%w: subject or email missing", ErrInvalidIDToken)
+	}
+	return id, nil
+}
+
+// stringsClaim reads a claim holding a list of strings, or a single string
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreateConnection(ctx context.Context, conn *Connection) error
+	GetConnection(ctx context.Context, id uuid.UUID) (*Connection, error)
+	GetConnectionBySlug(ctx context.Context, slug string) (*Connection, error)
+	GetConnectionByDomain(ctx context.Context, domain string) (*Connection, error)
+	ListConnections(ctx context.Context) ([]Connection, error)
+	UpdateConnection(ctx context.Context, conn *Connection) error
+	DeleteConnection(ctx context.Context, id uuid.UUID) error
+
+	CreateLoginState(ctx context.Context, state *LoginState) error
+	TakeLoginState(ctx context.Context, id string, now time.Time) (*LoginState, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateConnection(ctx context.Context, conn *Connection) error {
+	if err := r.db.WithContext(ctx).Create(conn).Error; err != nil {
+		return fmt.Errorf("failed to create SSO connection: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetConnection(ctx context.Context, id uuid.UUID) (*Connection, error) {
+	return r.getConnection(ctx, "id = ?", id)
+}
+
+func (r *repository) GetConnectionBySlug(ctx context.Context, slug string) (*Connection, error) {
+	return r.getConnection(ctx, "slug = ?", slug)
+}
+
+func (r *repository) GetConnectionByDomain(ctx context.Context, domain string) (*Connection, error) {
+	domains, err := json.Marshal([]string{domain})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode email domain: %w", err)
+	}
+	return r.getConnection(ctx, "email_domains @> ?::jsonb", string(domains))
+}
+
+func (r *repository) getConnection(ctx context.Context, query string, arg interface{}) (*Connection, error) {
+	var conn Connection
+	err := r.db.WithContext(ctx).Where(query, arg).First(&conn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get SSO connection: %w", err)
+	}
+	return &conn, nil
+}
+
+func (r *repository) ListConnections(ctx context.Context) ([]Connection, error) {
+	var conns []Connection
+	if err := r.db.WithContext(ctx).Order("name").Find(&conns).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SSO connections: %w", err)
+	}
+	return conns, nil
+}
+
+func (r *repository) UpdateConnection(ctx context.Context, conn *Connection) error {
+	if err := r.db.WithContext(ctx).Save(conn).Error; err != nil {
+		return fmt.Errorf("failed to update SSO connection: %w", err)
+	}
+	return nil
+}
+
+// DeleteConnection deletes a connection with its pending sign-ins. The users it
+// provisioned are kept; they can no longer sign in.
+func (r *repository) DeleteConnection(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("connection_id = ?", id).Delete(&LoginState{}).Error; err != nil {
+			return fmt.Errorf("failed to delete SSO login states: %w", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(&Connection{}).Error; err != nil {
+			return fmt.Errorf("failed to delete SSO connection: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) CreateLoginState(ctx context.Context, state *LoginState) error {
+	if err := r.db.WithContext(ctx).Create(state).Error; err != nil {
+		return fmt.Errorf("failed to create SSO login state: %w", err)
+	}
+	return nil
+}
+
+// TakeLoginState deletes and returns a pending sign-in, so a state is only completed
+// once. Expired sign-ins are purged on the way.
+func (r *repository) TakeLoginState(ctx context.Context, id string, now time.Time) (*LoginState, error) {
+	var states []LoginState
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND expires_at > ?", id, now).
+		Delete(&states).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to take SSO login state: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&LoginState{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to purge SSO login states: %w", err)
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
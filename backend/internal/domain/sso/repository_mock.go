@@ -0,0 +1,78 @@
+package sso
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateConnection(ctx context.Context, conn *Connection) error {
+	args := m.Called(ctx, conn)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetConnection(ctx context.Context, id uuid.UUID) (*Connection, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Connection), args.Error(1)
+}
+
+func (m *MockRepository) GetConnectionBySlug(ctx context.Context, slug string) (*Connection, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Connection), args.Error(1)
+}
+
+func (m *MockRepository) GetConnectionByDomain(ctx context.Context, domain string) (*Connection, error) {
+	args := m.Called(ctx, domain)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Connection), args.Error(1)
+}
+
+func (m *MockRepository) ListConnections(ctx context.Context) ([]Connection, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Connection), args.Error(1)
+}
+
+func (m *MockRepository) UpdateConnection(ctx context.Context, conn *Connection) error {
+	args := m.Called(ctx, conn)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteConnection(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateLoginState(ctx context.Context, state *LoginState) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TakeLoginState(ctx context.Context, id string, now time.Time) (*LoginState, error) {
+	args := m.Called(ctx, id, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginState), args.Error(1)
+}
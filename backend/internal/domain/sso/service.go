@@ -0,0 +1,534 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// SecretEncryptor encrypts the client secrets of the connections, satisfied by
+// *security.Encryptor
+type SecretEncryptor interface {
+	EncryptString(plaintext string) (string, error)
+	DecryptString(ciphertext string) (string, error)
+}
+
+// UserProvisioner finds or creates the users signing in, satisfied by *user.Service
+type UserProvisioner interface {
+	ProvisionFromSSO(ctx context.Context, profile user.SSOProfile) (*user.User, bool, error)
+}
+
+// AuditLogger records the sign-ins and the users they create, satisfied by
+// *audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// NewSecretEncryptor creates the encryptor of the client secrets from a base64 32-byte
+// key. It returns nil when no key is configured, in which case connections cannot be
+// created nor used.
+func NewSecretEncryptor(encodedKey string) (SecretEncryptor, error) {
+	if encodedKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSO encryption key: %w", err)
+	}
+	encryptor, err := security.NewEncryptor(security.EncryptionConfig{PrimaryKey: key})
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSO encryption key: %w", err)
+	}
+	return encryptor, nil
+}
+
+// Config configures the sign-in flow
+type Config struct {
+	CallbackURL    string   // Public URL of the callback endpoint, registered at the providers
+	AllowedOrigins []string // Origins of the frontends users can be sent back to
+}
+
+// Service manages the SSO connections of the organizations and signs their members in
+// with OpenID Connect. A completed sign-in provisions the user and returns a session
+// token signed with a key derived from the current keyring secret, accepted by the Auth
+// middleware next to Auth0 tokens.
+type Service struct {
+	repo       Repository
+	users      UserProvisioner
+	encryptor  SecretEncryptor
+	keyring    *security.Keyring
+	config     Config
+	audit      AuditLogger
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]*provider
+}
+
+// NewService creates an SSO service. Without an encryptor, connections cannot be
+// created nor used.
+func NewService(repo Repository, users UserProvisioner, encryptor SecretEncryptor, keyring *security.Keyring, config Config) *Service {
+	return &Service{
+		repo:       repo,
+		users:      users,
+		encryptor:  encryptor,
+		keyring:    keyring,
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		providers:  make(map[string]*provider),
+	}
+}
+
+// SetAuditLogger records sign-ins in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// ListConnections lists the connections of all organizations
+func (s *Service) ListConnections(ctx context.Context) ([]Connection, error) {
+	return s.repo.ListConnections(ctx)
+}
+
+// GetConnection returns a connection
+func (s *Service) GetConnection(ctx context.Context, id uuid.UUID) (*Connection, error) {
+	conn, err := s.repo.GetConnection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, ErrConnectionNotFound
+	}
+	return conn, nil
+}
+
+// CreateConnection creates the connection of an organization, enabled
+func (s *Service) CreateConnection(ctx context.Context, req CreateConnectionRequest, createdBy *uuid.UUID) (*Connection, error) {
+	now := s.now()
+	conn := &Connection{
+		ID:           uuid.New(),
+		Name:         strings.TrimSpace(req.Name),
+		Slug:         strings.ToLower(strings.TrimSpace(req.Slug)),
+		EmailDomains: normalizeDomains(req.EmailDomains),
+		Issuer:       strings.TrimSpace(req.Issuer),
+		ClientID:     strings.TrimSpace(req.ClientID),
+		Scopes:       nonNil(req.Scopes),
+		GroupsClaim:  req.GroupsClaim,
+		RoleMappings: req.RoleMappings,
+		DefaultRole:  req.DefaultRole,
+		FestivalIDs:  req.FestivalIDs,
+		Enabled:      true,
+		CreatedBy:    createdBy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.validate(ctx, conn); err != nil {
+		return nil, err
+	}
+	if existing, err := s.repo.GetConnectionBySlug(ctx, conn.Slug); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrSlugTaken
+	}
+	if err := s.setSecret(conn, req.ClientSecret); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateConnection(ctx, conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// UpdateConnection updates a connection. The users it provisioned get their new role
+// on their next sign-in.
+func (s *Service) UpdateConnection(ctx context.Context, id uuid.UUID, req UpdateConnectionRequest) (*Connection, error) {
+	conn, err := s.GetConnection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		conn.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.EmailDomains != nil {
+		conn.EmailDomains = normalizeDomains(req.EmailDomains)
+	}
+	if req.Issuer != nil {
+		conn.Issuer = strings.TrimSpace(*req.Issuer)
+	}
+	if req.ClientID != nil {
+		conn.ClientID = strings.TrimSpace(*req.ClientID)
+	}
+	if req.Scopes != nil {
+		conn.Scopes = req.Scopes
+	}
+	if req.GroupsClaim != nil {
+		conn.GroupsClaim = *req.GroupsClaim
+	}
+	if req.RoleMappings != nil {
+		conn.RoleMappings = req.RoleMappings
+	}
+	if req.DefaultRole != nil {
+		conn.DefaultRole = *req.DefaultRole
+	}
+	if req.FestivalIDs != nil {
+		conn.FestivalIDs = req.FestivalIDs
+	}
+	if req.Enabled != nil {
+		conn.Enabled = *req.Enabled
+	}
+	if err := s.validate(ctx, conn); err != nil {
+		return nil, err
+	}
+	if req.ClientSecret != nil {
+		if err := s.setSecret(conn, *req.ClientSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	conn.UpdatedAt = s.now()
+	if err := s.repo.UpdateConnection(ctx, conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// DeleteConnection deletes a connection. The users it provisioned are kept but can no
+// longer sign in with it.
+func (s *Service) DeleteConnection(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetConnection(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteConnection(ctx, id)
+}
+
+// Discover returns the connection the owner of an email signs in with, from its domain
+func (s *Service) Discover(ctx context.Context, email string) (*Discovery, error) {
+	domain := emailDomain(strings.TrimSpace(email))
+	if domain == "" {
+		return nil, ErrNoConnection
+	}
+	conn, err := s.repo.GetConnectionByDomain(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil || !conn.Enabled {
+		return nil, ErrNoConnection
+	}
+	return &Discovery{
+		Name:     conn.Name,
+		Slug:     conn.Slug,
+		LoginURL: strings.TrimSuffix(s.config.CallbackURL, "/callback") + "/" + conn.Slug + "/login",
+	}, nil
+}
+
+// StartLogin starts a sign-in with a connection and returns the URL of the provider to
+// send the user to. Once signed in, the user is sent back to redirect, which must be on
+// an allowed origin.
+func (s *Service) StartLogin(ctx context.Context, slug, redirect string) (string, error) {
+	if !s.allowedRedirect(redirect) {
+		return "", ErrInvalidRedirect
+	}
+	conn, err := s.repo.GetConnectionBySlug(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	if conn == nil {
+		return "", ErrConnectionNotFound
+	}
+	if !conn.Enabled {
+		return "", ErrConnectionDisabled
+	}
+
+	p, err := s.provider(ctx, conn.Issuer, false)
+	if err != nil {
+		return "", err
+	}
+
+	state := &LoginState{
+		ConnectionID: conn.ID,
+		Redirect:     redirect,
+		ExpiresAt:    s.now().Add(LoginStateTTL),
+		CreatedAt:    s.now(),
+	}
+	for _, value := range []*string{&state.ID, &state.Nonce, &state.CodeVerifier} {
+		if *value, err = randomToken(); err != nil {
+			return "", err
+		}
+	}
+	authURL, err := s.authorizationURL(p, conn, state)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateLoginState(ctx, state); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// CompleteLogin completes the sign-in a provider sent the user back from: the code is
+// exchanged for an ID token, whose email must be in the domains of the connection and
+// whose groups must map to a role. The user is created on their first sign-in and gets
+// the mapped role; the returned session token is accepted by the Auth middleware.
+func (s *Service) CompleteLogin(ctx context.Context, stateID, code string, info RequestInfo) (*Session, error) {
+	state, err := s.repo.TakeLoginState(ctx, stateID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrInvalidState
+	}
+	conn, err := s.repo.GetConnection(ctx, state.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, ErrConnectionNotFound
+	}
+	if !conn.Enabled {
+		return nil, ErrConnectionDisabled
+	}
+
+	session, id, created, err := s.signIn(ctx, conn, state, code)
+	if err != nil {
+		email := ""
+		if id != nil {
+			email = id.Email
+		}
+		s.logSignIn(ctx, audit.ActionLoginFailed, conn, nil, info, map[string]interface{}{
+			"email": email,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	if created {
+		s.logSignIn(ctx, audit.ActionUserCreate, conn, &session.User, info, map[string]interface{}{
+			"role": session.User.Role,
+		})
+	}
+	s.logSignIn(ctx, audit.ActionLogin, conn, &session.User, info, map[string]interface{}{
+		"subject": id.Subject,
+		"groups":  id.Groups,
+		"role":    session.User.Role,
+	})
+	return session, nil
+}
+
+// signIn verifies the sign-in of a user with a connection and provisions them. The
+// identity is returned as far as it was established.
+func (s *Service) signIn(ctx context.Context, conn *Connection, state *LoginState, code string) (*Session, *identity, bool, error) {
+	if s.encryptor == nil {
+		return nil, nil, false, ErrSecretsUnavailable
+	}
+	clientSecret, err := s.encryptor.DecryptString(conn.ClientSecret)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to decrypt SSO client secret: %w", err)
+	}
+
+	p, err := s.provider(ctx, conn.Issuer, false)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	rawIDToken, err := s.exchangeCode(ctx, p, conn, clientSecret, code, state.CodeVerifier)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	id, err := s.verifyIDToken(ctx, p, conn, rawIDToken, state.Nonce)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if !conn.HasDomain(id.Email) {
+		return nil, id, false, ErrEmailDomainMismatch
+	}
+	role, ok := conn.MapRole(id.Groups)
+	if !ok {
+		return nil, id, false, ErrNoMappedRole
+	}
+
+	u, created, err := s.users.ProvisionFromSSO(ctx, user.SSOProfile{
+		Subject:    SubjectPrefix + conn.ID.String() + "|" + id.Subject,
+		Email:      id.Email,
+		Name:       id.Name,
+		Role:       role,
+		Connection: conn.Name,
+	})
+	if err != nil {
+		return nil, id, false, err
+	}
+
+	token, expiresAt, err := s.issueSession(conn, u, id)
+	if err != nil {
+		return nil, id, false, err
+	}
+	return &Session{Token: token, ExpiresAt: expiresAt, Redirect: state.Redirect, User: *u}, id, created, nil
+}
+
+// issueSession signs the session token of a user signed in with a connection.
+// Organizers manage the festivals of their organization.
+func (s *Service) issueSession(conn *Connection, u *user.User, id *identity) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(SessionTTL)
+
+	claims := security.SessionClaims{
+		Email:         u.Email,
+		EmailVerified: true,
+		Name:          u.Name,
+		Roles:         []string{string(u.Role)},
+		AMR:           id.AMR,
+		Method:        security.SessionMethodSSO,
+		SSOConnection: conn.Slug,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   u.Auth0ID,
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if u.Role == user.UserRoleOrganizer {
+		for _, festivalID := range conn.FestivalIDs {
+			claims.OrganizerFor = append(claims.OrganizerFor, festivalID.String())
+		}
+	}
+	for _, method := range id.AMR {
+		if method == "mfa" {
+			claims.MFATime = id.AuthTime
+		}
+	}
+
+	signed, err := security.SignSessionToken(s.keyring, claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// validate checks a connection and normalizes its defaults. No two connections share
+// an email domain, so discovery is unambiguous.
+func (s *Service) validate(ctx context.Context, conn *Connection) error {
+	issuer, err := url.Parse(conn.Issuer)
+	if err != nil || issuer.Scheme != "https" || issuer.Host == "" ||
+		!slugPattern.MatchString(conn.Slug) || conn.Name == "" || conn.ClientID == "" || len(conn.EmailDomains) == 0 {
+		return ErrInvalidConnection
+	}
+	for _, domain := range conn.EmailDomains {
+		if domain == "" || strings.Contains(domain, "@") {
+			return ErrInvalidConnection
+		}
+		existing, err := s.repo.GetConnectionByDomain(ctx, domain)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != conn.ID {
+			return ErrDomainTaken
+		}
+	}
+
+	for _, m := range conn.RoleMappings {
+		if m.Group == "" || !mappable(m.Role) {
+			return ErrInvalidRoleMapping
+		}
+	}
+	if conn.DefaultRole != "" && !mappable(conn.DefaultRole) {
+		return ErrInvalidRoleMapping
+	}
+
+	if conn.GroupsClaim == "" {
+		conn.GroupsClaim = DefaultGroupsClaim
+	}
+	if conn.RoleMappings == nil {
+		conn.RoleMappings = []RoleMapping{}
+	}
+	if conn.FestivalIDs == nil {
+		conn.FestivalIDs = []uuid.UUID{}
+	}
+	return nil
+}
+
+func (s *Service) setSecret(conn *Connection, clientSecret string) error {
+	if s.encryptor == nil {
+		return ErrSecretsUnavailable
+	}
+	encrypted, err := s.encryptor.EncryptString(clientSecret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt SSO client secret: %w", err)
+	}
+	conn.ClientSecret = encrypted
+	return nil
+}
+
+// allowedRedirect reports whether redirect is an absolute URL on an allowed origin
+func (s *Service) allowedRedirect(redirect string) bool {
+	u, err := url.Parse(redirect)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil {
+		return false
+	}
+	origin := u.Scheme + "://" + u.Host
+	for _, allowed := range s.config.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) logSignIn(ctx context.Context, action audit.AuditAction, conn *Connection, u *user.User, info RequestInfo, metadata map[string]interface{}) {
+	if s.audit == nil {
+		return
+	}
+	req := audit.CreateAuditLogRequest{
+		Action:    action,
+		Resource:  "user",
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Metadata:  metadata,
+	}
+	if u != nil {
+		req.UserID = &u.ID
+		req.ResourceID = u.ID.String()
+	}
+	metadata["method"] = "sso"
+	metadata["connection"] = conn.Slug
+	s.audit.LogActionAsync(ctx, req)
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(d)))
+	}
+	return normalized
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
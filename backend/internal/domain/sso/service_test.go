@@ -0,0 +1,329 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvisioner struct {
+	profiles []user.SSOProfile
+}
+
+func (p *fakeProvisioner) ProvisionFromSSO(ctx context.Context, profile user.SSOProfile) (*user.User, bool, error) {
+	p.profiles = append(p.profiles, profile)
+	return &user.User{
+		ID:      uuid.New(),
+		Email:   profile.Email,
+		Name:    profile.Name,
+		Role:    profile.Role,
+		Auth0ID: profile.Subject,
+		Status:  user.UserStatusActive,
+	}, len(p.profiles) == 1, nil
+}
+
+// testProvider is an OpenID Connect provider issuing ID tokens with the claims of the
+// test for the codes it handed out
+type testProvider struct {
+	t          *testing.T
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	clientID   string
+	claims     jwt.MapClaims
+	challenges map[string]string // Code challenge and nonce of the sign-ins, by code
+	nonces     map[string]string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &testProvider{
+		t:          t,
+		key:        key,
+		clientID:   "festivals-client",
+		challenges: make(map[string]string),
+		nonces:     make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(providerMetadata{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		public, err := jwk.FromRaw(&key.PublicKey)
+		require.NoError(t, err)
+		require.NoError(t, public.Set(jwk.KeyIDKey, "idp-key"))
+		set := jwk.NewSet()
+		require.NoError(t, set.AddKey(public))
+		json.NewEncoder(w).Encode(set)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		code := r.PostForm.Get("code")
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if p.challenges[code] != base64.RawURLEncoding.EncodeToString(verifier[:]) || r.PostForm.Get("client_secret") != "s3cret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(tokenResponse{IDToken: p.idToken(p.nonces[code])})
+	})
+	p.server = httptest.NewTLSServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize signs the user in at the provider and returns the code for the callback
+func (p *testProvider) authorize(authURL, code string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(p.t, err)
+	q := u.Query()
+	require.Equal(p.t, "S256", q.Get("code_challenge_method"))
+	p.challenges[code] = q.Get("code_challenge")
+	p.nonces[code] = q.Get("nonce")
+	return q.Get("state")
+}
+
+func (p *testProvider) idToken(nonce string) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   p.clientID,
+		"sub":   "00u1abc",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	signed, err := token.SignedString(p.key)
+	require.NoError(p.t, err)
+	return signed
+}
+
+func newTestService(t *testing.T, p *testProvider) (*Service, *MockRepository, *fakeProvisioner) {
+	encryptor, err := security.NewEncryptor(security.EncryptionConfig{PrimaryKey: make([]byte, 32)})
+	require.NoError(t, err)
+	mockRepo := NewMockRepository()
+	users := &fakeProvisioner{}
+	svc := NewService(mockRepo, users, encryptor, security.NewKeyring("test-secret", nil, 0), Config{
+		CallbackURL:    "https://api.festivals.app/api/v1/auth/sso/callback",
+		AllowedOrigins: []string{"https://admin.festivals.app"},
+	})
+	svc.httpClient = p.server.Client()
+	return svc, mockRepo, users
+}
+
+// expectCreate stores the next connection created, which the repository returns from
+// then on by ID, slug and email domain
+func expectCreate(mockRepo *MockRepository) *Connection {
+	stored := &Connection{}
+	mockRepo.On("CreateConnection", mock.Anything, mock.AnythingOfType("*sso.Connection")).
+		Run(func(args mock.Arguments) {
+			*stored = *args.Get(1).(*Connection)
+			mockRepo.On("GetConnection", mock.Anything, stored.ID).Return(stored, nil).Maybe()
+			mockRepo.On("GetConnectionBySlug", mock.Anything, stored.Slug).Return(stored, nil).Maybe()
+			for _, domain := range stored.EmailDomains {
+				mockRepo.On("GetConnectionByDomain", mock.Anything, domain).Return(stored, nil).Maybe()
+			}
+		}).
+		Return(nil).Once()
+	return stored
+}
+
+// expectLoginStates stores the login states started, each taken once
+func expectLoginStates(mockRepo *MockRepository) {
+	mockRepo.On("CreateLoginState", mock.Anything, mock.AnythingOfType("*sso.LoginState")).
+		Run(func(args mock.Arguments) {
+			state := args.Get(1).(*LoginState)
+			mockRepo.On("TakeLoginState", mock.Anything, state.ID, mock.Anything).Return(state, nil).Once()
+		}).
+		Return(nil)
+}
+
+func createAcmeConnection(t *testing.T, svc *Service, mockRepo *MockRepository, p *testProvider) *Connection {
+	mockRepo.On("GetConnectionByDomain", mock.Anything, "acme.com").Return(nil, nil).Once()
+	mockRepo.On("GetConnectionBySlug", mock.Anything, "acme").Return(nil, nil).Once()
+	expectCreate(mockRepo)
+
+	conn, err := svc.CreateConnection(context.Background(), CreateConnectionRequest{
+		Name:         "Acme Events",
+		Slug:         "acme",
+		EmailDomains: []string{"Acme.com"},
+		Issuer:       p.server.URL,
+		ClientID:     p.clientID,
+		ClientSecret: "s3cret",
+		RoleMappings: []RoleMapping{
+			{Group: "festival-staff", Role: user.UserRoleStaff},
+			{Group: "festival-organizers", Role: user.UserRoleOrganizer},
+		},
+		FestivalIDs: []uuid.UUID{uuid.New()},
+	}, nil)
+	require.NoError(t, err)
+	return conn
+}
+
+func TestService_CompleteLogin(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+	svc, mockRepo, users := newTestService(t, p)
+	conn := createAcmeConnection(t, svc, mockRepo, p)
+	expectLoginStates(mockRepo)
+	assert.NotEqual(t, "s3cret", conn.ClientSecret)
+
+	discovery, err := svc.Discover(ctx, "jane@ACME.com")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.festivals.app/api/v1/auth/sso/acme/login", discovery.LoginURL)
+
+	_, err = svc.StartLogin(ctx, "acme", "https://evil.example/steal")
+	assert.ErrorIs(t, err, ErrInvalidRedirect)
+
+	authURL, err := svc.StartLogin(ctx, "acme", "https://admin.festivals.app/dashboard")
+	require.NoError(t, err)
+	state := p.authorize(authURL, "code-1")
+
+	p.claims = jwt.MapClaims{
+		"email":  "Jane@acme.com",
+		"name":   "Jane Doe",
+		"groups": []string{"festival-staff", "festival-organizers", "marketing"},
+		"amr":    []string{"pwd", "mfa"},
+	}
+	session, err := svc.CompleteLogin(ctx, state, "code-1", RequestInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://admin.festivals.app/dashboard", session.Redirect)
+
+	// The role with the most access among the groups is granted
+	require.Len(t, users.profiles, 1)
+	assert.Equal(t, user.UserRoleOrganizer, users.profiles[0].Role)
+	assert.Equal(t, "jane@acme.com", users.profiles[0].Email)
+	assert.Equal(t, "oidc|"+conn.ID.String()+"|00u1abc", users.profiles[0].Subject)
+
+	claims := security.SessionClaims{}
+	_, err = jwt.ParseWithClaims(session.Token, &claims, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, security.Fingerprint([]byte("test-secret")), token.Header["kid"])
+		return security.SessionTokenKey([]byte("test-secret")), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, security.SessionTokenIssuer, claims.Issuer)
+	assert.Equal(t, users.profiles[0].Subject, claims.Subject)
+	assert.Equal(t, []string{"ORGANIZER"}, claims.Roles)
+	assert.Equal(t, []string{conn.FestivalIDs[0].String()}, claims.OrganizerFor)
+	assert.NotZero(t, claims.MFATime)
+
+	// A state completes a single sign-in
+	mockRepo.On("TakeLoginState", mock.Anything, state, mock.Anything).Return(nil, nil).Once()
+	_, err = svc.CompleteLogin(ctx, state, "code-1", RequestInfo{})
+	assert.ErrorIs(t, err, ErrInvalidState)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CompleteLogin_Rejected(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+	svc, mockRepo, users := newTestService(t, p)
+	createAcmeConnection(t, svc, mockRepo, p)
+	expectLoginStates(mockRepo)
+
+	login := func(code string, claims jwt.MapClaims) error {
+		authURL, err := svc.StartLogin(ctx, "acme", "https://admin.festivals.app/")
+		require.NoError(t, err)
+		state := p.authorize(authURL, code)
+		p.claims = claims
+		_, err = svc.CompleteLogin(ctx, state, code, RequestInfo{})
+		return err
+	}
+
+	err := login("foreign", jwt.MapClaims{"email": "jane@other.org", "groups": []string{"festival-organizers"}})
+	assert.ErrorIs(t, err, ErrEmailDomainMismatch)
+
+	err = login("unmapped", jwt.MapClaims{"email": "joe@acme.com", "groups": []string{"marketing"}})
+	assert.ErrorIs(t, err, ErrNoMappedRole)
+
+	err = login("replayed", jwt.MapClaims{"email": "joe@acme.com", "nonce": "another-sign-in"})
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	err = login("audience", jwt.MapClaims{"email": "joe@acme.com", "aud": "another-client"})
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	assert.Empty(t, users.profiles)
+}
+
+func TestService_CreateConnection_Validation(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+	svc, mockRepo, _ := newTestService(t, p)
+	createAcmeConnection(t, svc, mockRepo, p)
+
+	req := CreateConnectionRequest{
+		Name:         "Globex",
+		Slug:         "globex",
+		EmailDomains: []string{"globex.com"},
+		Issuer:       "https://login.globex.com",
+		ClientID:     "globex",
+		ClientSecret: "secret",
+	}
+
+	// The requests below look the domain up before the connection exists
+	mockRepo.On("GetConnectionByDomain", mock.Anything, "globex.com").Return(nil, nil).Times(4)
+	mockRepo.On("GetConnectionBySlug", mock.Anything, "globex").Return(nil, nil).Once()
+	stored := expectCreate(mockRepo)
+
+	admin := req
+	admin.RoleMappings = []RoleMapping{{Group: "it", Role: user.UserRoleAdmin}}
+	_, err := svc.CreateConnection(ctx, admin, nil)
+	assert.ErrorIs(t, err, ErrInvalidRoleMapping)
+
+	plain := req
+	plain.Issuer = "http://login.globex.com"
+	_, err = svc.CreateConnection(ctx, plain, nil)
+	assert.ErrorIs(t, err, ErrInvalidConnection)
+
+	shared := req
+	shared.EmailDomains = []string{"globex.com", "acme.com"}
+	_, err = svc.CreateConnection(ctx, shared, nil)
+	assert.ErrorIs(t, err, ErrDomainTaken)
+
+	taken := req
+	taken.Slug = "acme"
+	_, err = svc.CreateConnection(ctx, taken, nil)
+	assert.ErrorIs(t, err, ErrSlugTaken)
+
+	conn, err := svc.CreateConnection(ctx, req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultGroupsClaim, conn.GroupsClaim)
+
+	enabled := false
+	mockRepo.On("UpdateConnection", mock.Anything, mock.MatchedBy(func(c *Connection) bool {
+		return c.ID == stored.ID && !c.Enabled
+	})).Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Connection) }).Return(nil).Once()
+	_, err = svc.UpdateConnection(ctx, conn.ID, UpdateConnectionRequest{Enabled: &enabled})
+	require.NoError(t, err)
+	_, err = svc.Discover(ctx, "jane@globex.com")
+	assert.ErrorIs(t, err, ErrNoConnection)
+	mockRepo.AssertExpectations(t)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/mimi6060/festivals/backend/internal/pkg/response"
 )
 
@@ -22,6 +23,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	{
 		stands.POST("", h.Create)
 		stands.GET("", h.List)
+		stands.GET("/nearby", h.Nearby)
 		stands.GET("/:id", h.GetByID)
 		stands.PATCH("/:id", h.Update)
 		stands.DELETE("/:id", h.Delete)
@@ -62,12 +64,16 @@ func (h *Handler) Create(c *gin.Context) {
 
 	var req CreateStandRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
 	stand, err := h.service.Create(c.Request.Context(), festivalID, req)
 	if err != nil {
+		if i18n.IsInvalidText(err) {
+			response.BadRequest(c, "INVALID_TRANSLATIONS", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -84,6 +90,10 @@ func (h *Handler) Create(c *gin.Context) {
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(20)
 // @Param category query string false "Filter by category" Enums(food, drinks, merchandise, services, other)
+// @Param categoryId query string false "Filter by festival category, including subcategories" format(uuid)
+// @Param lat query number false "Caller latitude, adds distanceMeters to each stand"
+// @Param lng query number false "Caller longitude, adds distanceMeters to each stand"
+// @Param lang query string false "Languages to localize names and descriptions in, before Accept-Language" example(fr-BE)
 // @Success 200 {object} response.Response{data=[]StandResponse,meta=response.Meta} "Stand list"
 // @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
@@ -100,6 +110,30 @@ func (h *Handler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
 	category := c.Query("category")
+	position := parsePosition(c)
+	notes := h.annotations(c, festivalID)
+
+	if categoryIDStr := c.Query("categoryId"); categoryIDStr != "" {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid category ID", nil)
+			return
+		}
+
+		stands, err := h.service.ListByCategoryID(c.Request.Context(), festivalID, categoryID)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		items := make([]StandResponse, len(stands))
+		for i := range stands {
+			items[i] = toPublicResponse(&stands[i], position, notes)
+		}
+
+		response.OK(c, items)
+		return
+	}
 
 	if category != "" {
 		stands, err := h.service.ListByCategory(c.Request.Context(), festivalID, StandCategory(category))
@@ -109,8 +143,8 @@ func (h *Handler) List(c *gin.Context) {
 		}
 
 		items := make([]StandResponse, len(stands))
-		for i, s := range stands {
-			items[i] = s.ToResponse()
+		for i := range stands {
+			items[i] = toPublicResponse(&stands[i], position, notes)
 		}
 
 		response.OK(c, items)
@@ -124,8 +158,8 @@ func (h *Handler) List(c *gin.Context) {
 	}
 
 	items := make([]StandResponse, len(stands))
-	for i, s := range stands {
-		items[i] = s.ToResponse()
+	for i := range stands {
+		items[i] = toPublicResponse(&stands[i], position, notes)
 	}
 
 	response.OKWithMeta(c, items, &response.Meta{
@@ -135,12 +169,61 @@ func (h *Handler) List(c *gin.Context) {
 	})
 }
 
+// Nearby lists stands around the caller
+// @Summary List nearby stands
+// @Description Get the stands within a radius of a position, nearest first, with their distance and whether they are open now
+// @Tags stands
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radius query number false "Radius in meters" default(500) maximum(5000)
+// @Param open_now query bool false "Only return stands that are open now" default(false)
+// @Param limit query int false "Maximum number of stands" default(50) maximum(100)
+// @Param lang query string false "Languages to localize names and descriptions in, before Accept-Language" example(fr-BE)
+// @Success 200 {object} response.Response{data=[]StandResponse} "Nearby stands"
+// @Failure 400 {object} response.ErrorResponse "Invalid position"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/stands/nearby [get]
+func (h *Handler) Nearby(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	var query NearbyQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid position", err.Error())
+		return
+	}
+
+	stands, err := h.service.ListNearby(c.Request.Context(), festivalID, query)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	notes := h.annotations(c, festivalID)
+
+	items := make([]StandResponse, len(stands))
+	for i := range stands {
+		items[i] = stands[i].ToResponse()
+		notes.apply(&items[i])
+	}
+
+	response.OK(c, items)
+}
+
 // GetByID gets a stand by ID
 // @Summary Get stand by ID
 // @Description Get detailed information about a specific stand
 // @Tags stands
 // @Produce json
 // @Param id path string true "Stand ID" format(uuid)
+// @Param lang query string false "Languages to localize the name and description in, before Accept-Language" example(fr-BE)
 // @Success 200 {object} response.Response{data=StandResponse} "Stand details"
 // @Failure 400 {object} response.ErrorResponse "Invalid stand ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
@@ -165,7 +248,10 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
-	response.OK(c, stand.ToResponse())
+	resp := stand.ToResponse()
+	h.annotations(c, stand.FestivalID).apply(&resp)
+
+	response.OK(c, resp)
 }
 
 // Update updates a stand
@@ -192,7 +278,7 @@ func (h *Handler) Update(c *gin.Context) {
 
 	var req UpdateStandRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -202,6 +288,10 @@ func (h *Handler) Update(c *gin.Context) {
 			response.NotFound(c, "Stand not found")
 			return
 		}
+		if i18n.IsInvalidText(err) {
+			response.BadRequest(c, "INVALID_TRANSLATIONS", err.Error(), nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -363,7 +453,7 @@ func (h *Handler) AssignStaff(c *gin.Context) {
 
 	var req AssignStaffRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -455,7 +545,7 @@ func (h *Handler) ValidatePIN(c *gin.Context) {
 		PIN string `json:"pin" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -505,6 +595,57 @@ func (h *Handler) GetMyStands(c *gin.Context) {
 
 // Helper functions
 
+type position struct {
+	lat, lng float64
+}
+
+// parsePosition reads the optional lat/lng query parameters of the caller
+func parsePosition(c *gin.Context) *position {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil
+	}
+	return &position{lat: lat, lng: lng}
+}
+
+// annotations holds the live per-stand data and the languages asked, added to public
+// stand responses
+type annotations struct {
+	waits     map[uuid.UUID]int
+	ratings   map[uuid.UUID]Rating
+	languages []string
+}
+
+func (h *Handler) annotations(c *gin.Context, festivalID uuid.UUID) annotations {
+	return annotations{
+		waits:     h.service.WaitMinutes(c.Request.Context(), festivalID),
+		ratings:   h.service.Ratings(c.Request.Context(), festivalID),
+		languages: i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language")),
+	}
+}
+
+// apply localizes a stand response and sets its estimated wait and attendee rating
+func (a annotations) apply(resp *StandResponse) {
+	resp.Localize(a.languages)
+	if minutes, ok := a.waits[resp.ID]; ok {
+		resp.WaitMinutes = &minutes
+	}
+	if rating, ok := a.ratings[resp.ID]; ok {
+		resp.Rating = &rating
+	}
+}
+
+// toPublicResponse annotates a stand with its distance to the caller, estimated wait and rating
+func toPublicResponse(stand *Stand, pos *position, notes annotations) StandResponse {
+	resp := stand.ToResponse()
+	if pos != nil {
+		resp.Distance = stand.DistanceTo(pos.lat, pos.lng)
+	}
+	notes.apply(&resp)
+	return resp
+}
+
 func getFestivalID(c *gin.Context) (uuid.UUID, error) {
 	festivalIDStr := c.GetString("festival_id")
 	if festivalIDStr == "" {
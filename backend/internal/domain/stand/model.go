@@ -1,9 +1,11 @@
 package stand
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 )
 
 // Stand represents a point of sale at a festival
@@ -12,11 +14,20 @@ type Stand struct {
 	FestivalID  uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
 	Name        string       `json:"name" gorm:"not null"`
 	Description string       `json:"description"`
+	NameTranslations        i18n.Text `json:"nameTranslations,omitempty" gorm:"type:jsonb;default:'{}'"` // Keyed by language tag, including the festival's default locale
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations,omitempty" gorm:"type:jsonb;default:'{}'"`
 	Category    StandCategory `json:"category" gorm:"not null"`
+	CategoryID  *uuid.UUID   `json:"categoryId,omitempty" gorm:"type:uuid;index"` // Festival-level category
 	Location    string       `json:"location"` // Physical location/zone in festival
+	Latitude    *float64     `json:"latitude,omitempty"`
+	Longitude   *float64     `json:"longitude,omitempty"`
+	OpensAt     *string      `json:"opensAt,omitempty" gorm:"type:time"`  // Daily opening time (festival local time)
+	ClosesAt    *string      `json:"closesAt,omitempty" gorm:"type:time"` // Daily closing time, may be past midnight
 	ImageURL    string       `json:"imageUrl,omitempty"`
 	Status      StandStatus  `json:"status" gorm:"default:'ACTIVE'"`
 	Settings    StandSettings `json:"settings" gorm:"type:jsonb;default:'{}'"`
+	CommissionPercent float64 `json:"commissionPercent" gorm:"type:numeric(5,2);default:0"` // Festival's cut of vendor sales, deducted on statements
+	CardFeePercent    float64 `json:"cardFeePercent" gorm:"type:numeric(5,2);default:0"`    // Card processing fees on the stand's card sales, shown on the profitability report
 	CreatedAt   time.Time    `json:"createdAt"`
 	UpdatedAt   time.Time    `json:"updatedAt"`
 }
@@ -77,21 +88,39 @@ const (
 type CreateStandRequest struct {
 	Name        string        `json:"name" binding:"required"`
 	Description string        `json:"description"`
+	NameTranslations        i18n.Text `json:"nameTranslations"` // Must include the festival's default locale, whose text becomes the name
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations"`
 	Category    StandCategory `json:"category" binding:"required"`
+	CategoryID  *uuid.UUID    `json:"categoryId"`
 	Location    string        `json:"location"`
+	Latitude    *float64      `json:"latitude" binding:"omitempty,latitude"`
+	Longitude   *float64      `json:"longitude" binding:"omitempty,longitude"`
+	OpensAt     *string       `json:"opensAt" binding:"omitempty,datetime=15:04"`
+	ClosesAt    *string       `json:"closesAt" binding:"omitempty,datetime=15:04"`
 	ImageURL    string        `json:"imageUrl"`
 	Settings    *StandSettings `json:"settings"`
+	CommissionPercent float64 `json:"commissionPercent" binding:"min=0,max=100"`
+	CardFeePercent    float64 `json:"cardFeePercent" binding:"min=0,max=100"`
 }
 
 // UpdateStandRequest represents the request to update a stand
 type UpdateStandRequest struct {
 	Name        *string        `json:"name,omitempty"`
 	Description *string        `json:"description,omitempty"`
+	NameTranslations        i18n.Text `json:"nameTranslations,omitempty"` // Replaces all translations; an empty object removes them
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations,omitempty"`
 	Category    *StandCategory `json:"category,omitempty"`
+	CategoryID  *uuid.UUID     `json:"categoryId,omitempty"`
 	Location    *string        `json:"location,omitempty"`
+	Latitude    *float64       `json:"latitude,omitempty" binding:"omitempty,latitude"`
+	Longitude   *float64       `json:"longitude,omitempty" binding:"omitempty,longitude"`
+	OpensAt     *string        `json:"opensAt,omitempty" binding:"omitempty,datetime=15:04"`
+	ClosesAt    *string        `json:"closesAt,omitempty" binding:"omitempty,datetime=15:04"`
 	ImageURL    *string        `json:"imageUrl,omitempty"`
 	Status      *StandStatus   `json:"status,omitempty"`
 	Settings    *StandSettings `json:"settings,omitempty"`
+	CommissionPercent *float64 `json:"commissionPercent,omitempty" binding:"omitempty,min=0,max=100"`
+	CardFeePercent    *float64 `json:"cardFeePercent,omitempty" binding:"omitempty,min=0,max=100"`
 }
 
 // AssignStaffRequest represents the request to assign staff to a stand
@@ -107,12 +136,23 @@ type StandResponse struct {
 	FestivalID  uuid.UUID     `json:"festivalId"`
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
+	NameTranslations        i18n.Text `json:"nameTranslations,omitempty"`
+	DescriptionTranslations i18n.Text `json:"descriptionTranslations,omitempty"`
 	Category    StandCategory `json:"category"`
+	CategoryID  *uuid.UUID    `json:"categoryId,omitempty"`
 	Location    string        `json:"location"`
+	Latitude    *float64      `json:"latitude,omitempty"`
+	Longitude   *float64      `json:"longitude,omitempty"`
+	OpensAt     *string       `json:"opensAt,omitempty"`
+	ClosesAt    *string       `json:"closesAt,omitempty"`
 	ImageURL    string        `json:"imageUrl,omitempty"`
 	Status      StandStatus   `json:"status"`
 	Settings    StandSettings `json:"settings"`
 	StaffCount  int           `json:"staffCount,omitempty"`
+	Distance    *float64      `json:"distanceMeters,omitempty"` // Set when the caller sent its position
+	OpenNow     *bool         `json:"openNow,omitempty"`
+	WaitMinutes *int          `json:"estimatedWaitMinutes,omitempty"`
+	Rating      *Rating       `json:"rating,omitempty"`
 	CreatedAt   string        `json:"createdAt"`
 	UpdatedAt   string        `json:"updatedAt"`
 }
@@ -123,8 +163,15 @@ func (s *Stand) ToResponse() StandResponse {
 		FestivalID:  s.FestivalID,
 		Name:        s.Name,
 		Description: s.Description,
+		NameTranslations:        s.NameTranslations,
+		DescriptionTranslations: s.DescriptionTranslations,
 		Category:    s.Category,
+		CategoryID:  s.CategoryID,
 		Location:    s.Location,
+		Latitude:    s.Latitude,
+		Longitude:   s.Longitude,
+		OpensAt:     s.OpensAt,
+		ClosesAt:    s.ClosesAt,
 		ImageURL:    s.ImageURL,
 		Status:      s.Status,
 		Settings:    s.Settings,
@@ -133,6 +180,73 @@ func (s *Stand) ToResponse() StandResponse {
 	}
 }
 
+// Localize sets the name and description in the best of the languages asked, keeping
+// the text in the festival's default locale when there is no translation
+func (r *StandResponse) Localize(languages []string) {
+	r.Name = r.NameTranslations.Localize(languages, r.Name)
+	r.Description = r.DescriptionTranslations.Localize(languages, r.Description)
+}
+
+// Rating is the aggregated attendee rating of a stand
+type Rating struct {
+	Average float64 `json:"average"` // 1 to 5, rounded to one decimal
+	Count   int     `json:"count"`
+}
+
+// Nearby search limits, in meters
+const (
+	DefaultNearbyRadius = 500
+	MaxNearbyRadius     = 5000
+)
+
+// NearbyQuery describes a "near me" stand lookup
+type NearbyQuery struct {
+	Latitude  float64 `form:"lat" binding:"required,latitude"`
+	Longitude float64 `form:"lng" binding:"required,longitude"`
+	Radius    float64 `form:"radius"`   // Meters, defaults to DefaultNearbyRadius
+	OpenNow   bool    `form:"open_now"` // Only return stands open at the festival's local time
+	Limit     int     `form:"limit"`
+}
+
+// NearbyStand is a stand found by a geo query with its distance to the caller
+type NearbyStand struct {
+	Stand
+	Distance float64 `gorm:"column:distance"`
+	OpenNow  bool    `gorm:"column:open_now"`
+}
+
+func (n *NearbyStand) ToResponse() StandResponse {
+	resp := n.Stand.ToResponse()
+	resp.Distance = &n.Distance
+	resp.OpenNow = &n.OpenNow
+	return resp
+}
+
+// HasPosition reports whether the stand has been placed on the map
+func (s *Stand) HasPosition() bool {
+	return s.Latitude != nil && s.Longitude != nil
+}
+
+// earthRadiusMeters is the mean Earth radius used for distance annotations
+const earthRadiusMeters = 6371000
+
+// DistanceTo returns the great-circle distance in meters between the stand and a
+// point, or nil when the stand has no position
+func (s *Stand) DistanceTo(lat, lng float64) *float64 {
+	if !s.HasPosition() {
+		return nil
+	}
+
+	lat1 := *s.Latitude * math.Pi / 180
+	lat2 := lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (lng - *s.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	distance := 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+	return &distance
+}
+
 // StandStaffResponse represents the API response for stand staff
 type StandStaffResponse struct {
 	ID        uuid.UUID `json:"id"`
@@ -14,6 +14,8 @@ type Repository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*Stand, error)
 	ListByFestival(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Stand, int64, error)
 	ListByCategory(ctx context.Context, festivalID uuid.UUID, category StandCategory) ([]Stand, error)
+	ListByCategoryIDs(ctx context.Context, festivalID uuid.UUID, categoryIDs []uuid.UUID) ([]Stand, error)
+	ListNearby(ctx context.Context, festivalID uuid.UUID, query NearbyQuery) ([]NearbyStand, error)
 	Update(ctx context.Context, stand *Stand) error
 	Delete(ctx context.Context, id uuid.UUID) error
 
@@ -78,6 +80,60 @@ func (r *repository) ListByCategory(ctx context.Context, festivalID uuid.UUID, c
 	return stands, nil
 }
 
+func (r *repository) ListByCategoryIDs(ctx context.Context, festivalID uuid.UUID, categoryIDs []uuid.UUID) ([]Stand, error) {
+	var stands []Stand
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND category_id IN ? AND status = ?", festivalID, categoryIDs, StandStatusActive).
+		Order("name ASC").
+		Find(&stands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stands by category IDs: %w", err)
+	}
+	return stands, nil
+}
+
+// openNowSQL evaluates the opening hours in the festival timezone. Hours where the
+// closing time is before the opening time span midnight (e.g. 18:00-02:00).
+const openNowSQL = `s.status = 'ACTIVE' AND (
+	s.opens_at IS NULL OR s.closes_at IS NULL OR
+	CASE WHEN s.opens_at <= s.closes_at
+		THEN (now() AT TIME ZONE f.timezone)::time >= s.opens_at AND (now() AT TIME ZONE f.timezone)::time < s.closes_at
+		ELSE (now() AT TIME ZONE f.timezone)::time >= s.opens_at OR (now() AT TIME ZONE f.timezone)::time < s.closes_at
+	END)`
+
+// ListNearby returns the active or closed stands within the radius of a point, nearest first
+func (r *repository) ListNearby(ctx context.Context, festivalID uuid.UUID, query NearbyQuery) ([]NearbyStand, error) {
+	sql := `
+		SELECT * FROM (
+			SELECT s.*,
+				ST_Distance(s.geo_point, ST_SetSRID(ST_MakePoint(@lng, @lat), 4326)::geography) AS distance,
+				` + openNowSQL + ` AS open_now
+			FROM stands s
+			INNER JOIN festivals f ON f.id = s.festival_id
+			WHERE s.festival_id = @festival
+				AND s.status IN ('ACTIVE', 'CLOSED')
+				AND s.geo_point IS NOT NULL
+				AND ST_DWithin(s.geo_point, ST_SetSRID(ST_MakePoint(@lng, @lat), 4326)::geography, @radius)
+		) nearby`
+	if query.OpenNow {
+		sql += ` WHERE open_now`
+	}
+	sql += ` ORDER BY distance ASC LIMIT @limit`
+
+	var stands []NearbyStand
+	err := r.db.WithContext(ctx).Raw(sql, map[string]interface{}{
+		"festival": festivalID,
+		"lat":      query.Latitude,
+		"lng":      query.Longitude,
+		"radius":   query.Radius,
+		"limit":    query.Limit,
+	}).Scan(&stands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nearby stands: %w", err)
+	}
+	return stands, nil
+}
+
 func (r *repository) Update(ctx context.Context, stand *Stand) error {
 	return r.db.WithContext(ctx).Save(stand).Error
 }
@@ -42,6 +42,16 @@ func (m *MockRepository) ListByCategory(ctx context.Context, festivalID uuid.UUI
 	return args.Get(0).([]Stand), args.Error(1)
 }
 
+func (m *MockRepository) ListByCategoryIDs(ctx context.Context, festivalID uuid.UUID, categoryIDs []uuid.UUID) ([]Stand, error) {
+	args := m.Called(ctx, festivalID, categoryIDs)
+	return args.Get(0).([]Stand), args.Error(1)
+}
+
+func (m *MockRepository) ListNearby(ctx context.Context, festivalID uuid.UUID, query NearbyQuery) ([]NearbyStand, error) {
+	args := m.Called(ctx, festivalID, query)
+	return args.Get(0).([]NearbyStand), args.Error(1)
+}
+
 func (m *MockRepository) Update(ctx context.Context, stand *Stand) error {
 	args := m.Called(ctx, stand)
 	return args.Error(0)
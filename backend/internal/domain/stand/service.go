@@ -9,16 +9,122 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"github.com/rs/zerolog/log"
 )
 
+// CategoryResolver resolves festival category hierarchy information
+type CategoryResolver interface {
+	DescendantIDs(ctx context.Context, categoryID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// WaitTimeProvider provides the estimated wait per stand of a festival
+type WaitTimeProvider interface {
+	GetWaitMinutes(ctx context.Context, festivalID uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+// RatingProvider provides the aggregated attendee rating per stand of a festival
+type RatingProvider interface {
+	GetStandRatings(ctx context.Context, festivalID uuid.UUID) (map[uuid.UUID]Rating, error)
+}
+
+// ActivityRecorder records staff logins in the organizer feed; satisfied by activity.Service
+type ActivityRecorder interface {
+	RecordStaffLogin(ctx context.Context, standID, userID uuid.UUID)
+}
+
+// LocaleResolver resolves the language a festival's stands are written in; satisfied by festival.Service
+type LocaleResolver interface {
+	DefaultLocale(ctx context.Context, festivalID uuid.UUID) (string, error)
+}
+
 type Service struct {
-	repo Repository
+	repo       Repository
+	categories CategoryResolver
+	waitTimes  WaitTimeProvider
+	ratings    RatingProvider
+	activity   ActivityRecorder
+	locales    LocaleResolver
 }
 
 func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetCategoryResolver enables filtering stands by category including subcategories
+func (s *Service) SetCategoryResolver(resolver CategoryResolver) {
+	s.categories = resolver
+}
+
+// SetActivityRecorder reports staff logins to the activity feed
+func (s *Service) SetActivityRecorder(recorder ActivityRecorder) {
+	s.activity = recorder
+}
+
+// SetLocaleResolver enables checking translations against the festival's default locale
+func (s *Service) SetLocaleResolver(resolver LocaleResolver) {
+	s.locales = resolver
+}
+
+// festivalLocale returns the default locale of a festival, i18n.Default without a resolver
+func (s *Service) festivalLocale(ctx context.Context, festivalID uuid.UUID) (string, error) {
+	if s.locales == nil {
+		return i18n.Default, nil
+	}
+	return s.locales.DefaultLocale(ctx, festivalID)
+}
+
+// DefaultLocale returns the default locale of the festival of a stand, which its
+// products are written in
+func (s *Service) DefaultLocale(ctx context.Context, standID uuid.UUID) (string, error) {
+	stand, err := s.repo.GetByID(ctx, standID)
+	if err != nil {
+		return "", err
+	}
+	if stand == nil {
+		return "", errors.ErrNotFound
+	}
+	return s.festivalLocale(ctx, stand.FestivalID)
+}
+
+// SetWaitTimeProvider enables wait-time annotations on stand responses
+func (s *Service) SetWaitTimeProvider(provider WaitTimeProvider) {
+	s.waitTimes = provider
+}
+
+// WaitMinutes returns the estimated wait per stand of a festival. Wait times are an
+// annotation only, so failures are logged and yield no estimates.
+func (s *Service) WaitMinutes(ctx context.Context, festivalID uuid.UUID) map[uuid.UUID]int {
+	if s.waitTimes == nil {
+		return nil
+	}
+	minutes, err := s.waitTimes.GetWaitMinutes(ctx, festivalID)
+	if err != nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to get stand wait times")
+		return nil
+	}
+	return minutes
+}
+
+// SetRatingProvider enables rating annotations on stand responses
+func (s *Service) SetRatingProvider(provider RatingProvider) {
+	s.ratings = provider
+}
+
+// Ratings returns the aggregated rating per stand of a festival. Like wait times,
+// ratings are an annotation only, so failures are logged and yield no ratings.
+func (s *Service) Ratings(ctx context.Context, festivalID uuid.UUID) map[uuid.UUID]Rating {
+	if s.ratings == nil {
+		return nil
+	}
+	ratings, err := s.ratings.GetStandRatings(ctx, festivalID)
+	if err != nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to get stand ratings")
+		return nil
+	}
+	return ratings
+}
+
 // Create creates a new stand
 func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, req CreateStandRequest) (*Stand, error) {
 	settings := StandSettings{
@@ -30,18 +136,40 @@ func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, req CreateSt
 		settings = *req.Settings
 	}
 
+	locale, err := s.festivalLocale(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	name, nameTranslations, err := i18n.UpdateText(locale, req.Name, nil, nil, req.NameTranslations)
+	if err != nil {
+		return nil, err
+	}
+	description, descriptionTranslations, err := i18n.UpdateText(locale, req.Description, nil, nil, req.DescriptionTranslations)
+	if err != nil {
+		return nil, err
+	}
+
 	stand := &Stand{
-		ID:          uuid.New(),
-		FestivalID:  festivalID,
-		Name:        req.Name,
-		Description: req.Description,
-		Category:    req.Category,
-		Location:    req.Location,
-		ImageURL:    req.ImageURL,
-		Status:      StandStatusActive,
-		Settings:    settings,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:                      uuid.New(),
+		FestivalID:              festivalID,
+		Name:                    name,
+		Description:             description,
+		NameTranslations:        nameTranslations,
+		DescriptionTranslations: descriptionTranslations,
+		Category:                req.Category,
+		CategoryID:              req.CategoryID,
+		Location:                req.Location,
+		Latitude:                req.Latitude,
+		Longitude:               req.Longitude,
+		OpensAt:                 req.OpensAt,
+		ClosesAt:                req.ClosesAt,
+		ImageURL:                req.ImageURL,
+		Status:                  StandStatusActive,
+		Settings:                settings,
+		CommissionPercent:       req.CommissionPercent,
+		CardFeePercent:          req.CardFeePercent,
+		CreatedAt:               time.Now(),
+		UpdatedAt:               time.Now(),
 	}
 
 	if err := s.repo.Create(ctx, stand); err != nil {
@@ -76,11 +204,38 @@ func (s *Service) List(ctx context.Context, festivalID uuid.UUID, page, perPage
 	return s.repo.ListByFestival(ctx, festivalID, offset, perPage)
 }
 
+// ListNearby lists the stands around a position, nearest first
+func (s *Service) ListNearby(ctx context.Context, festivalID uuid.UUID, query NearbyQuery) ([]NearbyStand, error) {
+	if query.Radius <= 0 {
+		query.Radius = DefaultNearbyRadius
+	}
+	if query.Radius > MaxNearbyRadius {
+		query.Radius = MaxNearbyRadius
+	}
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 50
+	}
+	return s.repo.ListNearby(ctx, festivalID, query)
+}
+
 // ListByCategory lists stands by category
 func (s *Service) ListByCategory(ctx context.Context, festivalID uuid.UUID, category StandCategory) ([]Stand, error) {
 	return s.repo.ListByCategory(ctx, festivalID, category)
 }
 
+// ListByCategoryID lists active stands in a festival category or any of its subcategories
+func (s *Service) ListByCategoryID(ctx context.Context, festivalID, categoryID uuid.UUID) ([]Stand, error) {
+	categoryIDs := []uuid.UUID{categoryID}
+	if s.categories != nil {
+		ids, err := s.categories.DescendantIDs(ctx, categoryID)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs = ids
+	}
+	return s.repo.ListByCategoryIDs(ctx, festivalID, categoryIDs)
+}
+
 // Update updates a stand
 func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateStandRequest) (*Stand, error) {
 	stand, err := s.repo.GetByID(ctx, id)
@@ -92,18 +247,41 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateStandReque
 	}
 
 	// Apply updates
-	if req.Name != nil {
-		stand.Name = *req.Name
-	}
-	if req.Description != nil {
-		stand.Description = *req.Description
+	if req.Name != nil || req.Description != nil || req.NameTranslations != nil || req.DescriptionTranslations != nil {
+		locale, err := s.festivalLocale(ctx, stand.FestivalID)
+		if err != nil {
+			return nil, err
+		}
+		stand.Name, stand.NameTranslations, err = i18n.UpdateText(locale, stand.Name, stand.NameTranslations, req.Name, req.NameTranslations)
+		if err != nil {
+			return nil, err
+		}
+		stand.Description, stand.DescriptionTranslations, err = i18n.UpdateText(locale, stand.Description, stand.DescriptionTranslations, req.Description, req.DescriptionTranslations)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if req.Category != nil {
 		stand.Category = *req.Category
 	}
+	if req.CategoryID != nil {
+		stand.CategoryID = req.CategoryID
+	}
 	if req.Location != nil {
 		stand.Location = *req.Location
 	}
+	if req.Latitude != nil {
+		stand.Latitude = req.Latitude
+	}
+	if req.Longitude != nil {
+		stand.Longitude = req.Longitude
+	}
+	if req.OpensAt != nil {
+		stand.OpensAt = req.OpensAt
+	}
+	if req.ClosesAt != nil {
+		stand.ClosesAt = req.ClosesAt
+	}
 	if req.ImageURL != nil {
 		stand.ImageURL = *req.ImageURL
 	}
@@ -113,6 +291,12 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateStandReque
 	if req.Settings != nil {
 		stand.Settings = *req.Settings
 	}
+	if req.CommissionPercent != nil {
+		stand.CommissionPercent = *req.CommissionPercent
+	}
+	if req.CardFeePercent != nil {
+		stand.CardFeePercent = *req.CardFeePercent
+	}
 
 	stand.UpdatedAt = time.Now()
 
@@ -209,12 +393,12 @@ func (s *Service) ValidateStaffPIN(ctx context.Context, standID, userID uuid.UUI
 		return false, errors.ErrNotFound
 	}
 
-	if staff.PIN == "" {
-		// No PIN required
-		return true, nil
+	valid := staff.PIN == "" || staff.PIN == hashPIN(pin) // No PIN required when unset
+	if valid && s.activity != nil {
+		s.activity.RecordStaffLogin(ctx, standID, userID)
 	}
 
-	return staff.PIN == hashPIN(pin), nil
+	return valid, nil
 }
 
 // Activate activates a stand
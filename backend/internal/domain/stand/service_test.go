@@ -647,3 +647,47 @@ func TestHashPIN(t *testing.T) {
 		})
 	}
 }
+
+// TestService_ListNearby tests radius and limit defaults of ListNearby
+func TestService_ListNearby(t *testing.T) {
+	mockRepo := NewMockRepository()
+	festivalID := uuid.New()
+
+	mockRepo.On("ListNearby", mock.Anything, festivalID, NearbyQuery{
+		Latitude:  50.85,
+		Longitude: 4.35,
+		Radius:    MaxNearbyRadius,
+		Limit:     50,
+	}).Return([]NearbyStand{}, nil)
+
+	service := NewService(mockRepo)
+
+	_, err := service.ListNearby(context.Background(), festivalID, NearbyQuery{
+		Latitude:  50.85,
+		Longitude: 4.35,
+		Radius:    100000,
+	})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestStand_DistanceTo tests the distance annotation of stands
+func TestStand_DistanceTo(t *testing.T) {
+	lat, lng := 50.8503, 4.3517
+
+	t.Run("no position", func(t *testing.T) {
+		stand := &Stand{}
+		assert.Nil(t, stand.DistanceTo(lat, lng))
+	})
+
+	t.Run("same position", func(t *testing.T) {
+		stand := &Stand{Latitude: &lat, Longitude: &lng}
+		assert.InDelta(t, 0, *stand.DistanceTo(lat, lng), 0.001)
+	})
+
+	t.Run("about 111m per 0.001 degree of latitude", func(t *testing.T) {
+		stand := &Stand{Latitude: &lat, Longitude: &lng}
+		assert.InDelta(t, 111.2, *stand.DistanceTo(lat+0.001, lng), 0.5)
+	})
+}
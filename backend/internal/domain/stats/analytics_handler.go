@@ -36,6 +36,7 @@ func (h *AnalyticsHandler) RegisterRoutes(r *gin.RouterGroup) {
 		// Funnel analysis
 		analytics.GET("/funnels", h.GetAllFunnels)
 		analytics.GET("/funnels/:name", h.GetFunnelAnalysis)
+		analytics.GET("/funnels/:name/drop-offs", h.GetFunnelDropOffs)
 		analytics.POST("/funnels", h.CreateCustomFunnel)
 
 		// Cohort analysis
@@ -46,6 +47,7 @@ func (h *AnalyticsHandler) RegisterRoutes(r *gin.RouterGroup) {
 
 		// User journey
 		analytics.GET("/users/:userId/journey", h.GetUserJourney)
+		analytics.GET("/sessions/:sessionId/timeline", h.GetSessionTimeline)
 
 		// Predictions
 		analytics.GET("/predictions", h.GetPredictions)
@@ -402,6 +404,73 @@ func (h *AnalyticsHandler) GetFunnelAnalysis(c *gin.Context) {
 	response.OK(c, funnel)
 }
 
+// GetFunnelDropOffs lists the sessions that dropped off a funnel before a step
+// @Summary Get funnel drop-offs
+// @Description List the sessions that completed the funnel steps before a step and never that step, to open their timelines
+// @Tags analytics
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param name path string true "Funnel name"
+// @Param step query int true "Index of the step not reached, from 1"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param limit query int false "Sessions to return (default 100, max 500)"
+// @Success 200 {object} FunnelDropOffs
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/analytics/funnels/{name}/drop-offs [get]
+func (h *AnalyticsHandler) GetFunnelDropOffs(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	funnelName := c.Param("name")
+	if funnelName == "" {
+		response.BadRequest(c, "INVALID_NAME", "Funnel name is required", nil)
+		return
+	}
+
+	step, err := strconv.Atoi(c.Query("step"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_STEP", "Step must be the index of a funnel step", nil)
+		return
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -30)
+
+	if startStr := c.Query("start_date"); startStr != "" {
+		if parsed, err := time.Parse("2006-01-02", startStr); err == nil {
+			start = parsed
+		}
+	}
+
+	if endStr := c.Query("end_date"); endStr != "" {
+		if parsed, err := time.Parse("2006-01-02", endStr); err == nil {
+			end = parsed.Add(24*time.Hour - time.Second)
+		}
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	dropOffs, err := h.service.GetFunnelDropOffs(c.Request.Context(), festivalID, funnelName, step, start, end, limit)
+	if err != nil {
+		switch err {
+		case errors.ErrFestivalNotFound:
+			response.NotFound(c, "Festival not found")
+		case ErrFunnelStepOutOfRange:
+			response.BadRequest(c, "INVALID_STEP", "Step is not a step of the funnel after the first", nil)
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, dropOffs)
+}
+
 // CreateCustomFunnel creates a custom funnel definition
 // @Summary Create custom funnel
 // @Description Create a custom funnel definition with specific steps
@@ -596,6 +665,47 @@ func (h *AnalyticsHandler) GetUserJourney(c *gin.Context) {
 	response.OK(c, journey)
 }
 
+// GetSessionTimeline retrieves the timeline of a session
+// @Summary Get session timeline
+// @Description Get the events, screens and orders of a session in order, with the entities they reference and, for a funnel, the step the session dropped off at
+// @Tags analytics
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param sessionId path string true "Session ID"
+// @Param funnel query string false "Funnel to mark the steps of"
+// @Success 200 {object} SessionTimeline
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/analytics/sessions/{sessionId}/timeline [get]
+func (h *AnalyticsHandler) GetSessionTimeline(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	sessionID := c.Param("sessionId")
+	if sessionID == "" {
+		response.BadRequest(c, "INVALID_SESSION_ID", "Session ID is required", nil)
+		return
+	}
+
+	timeline, err := h.service.GetSessionTimeline(c.Request.Context(), festivalID, sessionID, c.Query("funnel"))
+	if err != nil {
+		switch err {
+		case errors.ErrFestivalNotFound:
+			response.NotFound(c, "Festival not found")
+		case errors.ErrNotFound:
+			response.NotFound(c, "Session not found")
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, timeline)
+}
+
 // GetPredictions retrieves ML predictions
 // @Summary Get predictions
 // @Description Get ML-based predictions for the festival
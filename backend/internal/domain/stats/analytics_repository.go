@@ -36,6 +36,11 @@ type AnalyticsRepository interface {
 	// User journey
 	GetUserJourney(ctx context.Context, userID, festivalID uuid.UUID) (*UserJourney, error)
 
+	// Session timeline
+	GetUserOrders(ctx context.Context, festivalID, userID uuid.UUID, from, to time.Time) ([]TimelineOrder, error)
+	GetTimelineEntities(ctx context.Context, festivalID uuid.UUID, refs map[string][]uuid.UUID) ([]TimelineEntity, error)
+	GetFunnelDropOffs(ctx context.Context, festivalID uuid.UUID, steps []EventType, step int, start, end time.Time, limit int) ([]DroppedSession, error)
+
 	// Analytics summary
 	GetAnalyticsSummary(ctx context.Context, festivalID uuid.UUID, start, end time.Time) (*AnalyticsSummary, error)
 	GetRealTimeMetrics(ctx context.Context, festivalID uuid.UUID) (*RealTimeMetrics, error)
@@ -48,15 +53,16 @@ type AnalyticsRepository interface {
 
 // EventFilters contains filters for querying events
 type EventFilters struct {
-	EventTypes []EventType
-	StartDate  *time.Time
-	EndDate    *time.Time
-	UserID     *uuid.UUID
-	SessionID  string
-	Category   string
-	Platform   string
-	Limit      int
-	Offset     int
+	EventTypes  []EventType
+	StartDate   *time.Time
+	EndDate     *time.Time
+	UserID      *uuid.UUID
+	SessionID   string
+	Category    string
+	Platform    string
+	Limit       int
+	Offset      int
+	OldestFirst bool // Ordered by time then by reception, rather than latest first
 }
 
 type analyticsRepository struct {
@@ -182,7 +188,11 @@ func (r *analyticsRepository) GetEvents(ctx context.Context, festivalID uuid.UUI
 		args = append(args, filters.Platform)
 	}
 
-	query += " ORDER BY timestamp DESC"
+	if filters.OldestFirst {
+		query += " ORDER BY timestamp ASC, created_at ASC, id ASC"
+	} else {
+		query += " ORDER BY timestamp DESC"
+	}
 
 	if filters.Limit > 0 {
 		query += " LIMIT ?"
@@ -958,6 +968,144 @@ func (r *analyticsRepository) GetUserJourney(ctx context.Context, userID, festiv
 	return journey, nil
 }
 
+// GetUserOrders retrieves the orders a user placed in a festival between from and to
+func (r *analyticsRepository) GetUserOrders(ctx context.Context, festivalID, userID uuid.UUID, from, to time.Time) ([]TimelineOrder, error) {
+	var orders []TimelineOrder
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT id, stand_id, total_amount, status, payment_method,
+		       jsonb_array_length(items) AS items, created_at
+		FROM public.orders
+		WHERE festival_id = ? AND user_id = ? AND created_at >= ? AND created_at <= ?
+		ORDER BY created_at ASC`,
+		festivalID, userID, from, to,
+	).Scan(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user orders: %w", err)
+	}
+	return orders, nil
+}
+
+// timelineEntityQueries look up the entities of each type within a festival
+var timelineEntityQueries = map[string]string{
+	EntityStand: `
+		SELECT id, name, NULL::bigint AS amount, '' AS status
+		FROM public.stands WHERE festival_id = ? AND id IN ?`,
+	EntityProduct: `
+		SELECT p.id, p.name, p.price AS amount, '' AS status
+		FROM public.products p
+		JOIN public.stands s ON s.id = p.stand_id
+		WHERE s.festival_id = ? AND p.id IN ?`,
+	EntityOrder: `
+		SELECT id, '' AS name, total_amount AS amount, status
+		FROM public.orders WHERE festival_id = ? AND id IN ?`,
+	EntityTicketType: `
+		SELECT id, name, price AS amount, '' AS status
+		FROM public.ticket_types WHERE festival_id = ? AND id IN ?`,
+	EntityArtist: `
+		SELECT id, name, NULL::bigint AS amount, '' AS status
+		FROM public.artists WHERE festival_id = ? AND id IN ?`,
+}
+
+// GetTimelineEntities looks up the entities referenced by a timeline. Entities not
+// found in the festival are returned with Found false.
+func (r *analyticsRepository) GetTimelineEntities(ctx context.Context, festivalID uuid.UUID, refs map[string][]uuid.UUID) ([]TimelineEntity, error) {
+	var entities []TimelineEntity
+	for entityType, ids := range refs {
+		query, ok := timelineEntityQueries[entityType]
+		if !ok || len(ids) == 0 {
+			continue
+		}
+
+		var rows []struct {
+			ID     uuid.UUID
+			Name   string
+			Amount *int64
+			Status string
+		}
+		if err := r.db.WithContext(ctx).Raw(query, festivalID, ids).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to get timeline %s entities: %w", entityType, err)
+		}
+
+		found := make(map[uuid.UUID]bool, len(rows))
+		for _, row := range rows {
+			found[row.ID] = true
+			entities = append(entities, TimelineEntity{
+				Type:   entityType,
+				ID:     row.ID,
+				Name:   row.Name,
+				Amount: row.Amount,
+				Status: row.Status,
+				Found:  true,
+			})
+		}
+		for _, id := range ids {
+			if !found[id] {
+				entities = append(entities, TimelineEntity{Type: entityType, ID: id})
+			}
+		}
+	}
+	return entities, nil
+}
+
+// GetFunnelDropOffs retrieves the sessions that completed the funnel steps before step,
+// in order and between start and end, and never completed step afterwards. The sessions
+// that dropped off last come first.
+func (r *analyticsRepository) GetFunnelDropOffs(ctx context.Context, festivalID uuid.UUID, steps []EventType, step int, start, end time.Time, limit int) ([]DroppedSession, error) {
+	if step < 1 || step >= len(steps) {
+		return nil, fmt.Errorf("funnel step %d out of range", step)
+	}
+
+	// Time each session completed the steps before step, in order, as GetFunnelData does
+	query := `
+		WITH step0_times AS (
+			SELECT session_id, MIN(timestamp) AS step_time
+			FROM public.analytics_events
+			WHERE festival_id = ? AND type = ? AND timestamp >= ? AND timestamp <= ?
+			GROUP BY session_id
+		)`
+	args := []interface{}{festivalID, steps[0], start, end}
+	for i := 1; i < step; i++ {
+		query += fmt.Sprintf(`
+		, step%d_times AS (
+			SELECT e.session_id, MIN(e.timestamp) AS step_time
+			FROM public.analytics_events e
+			INNER JOIN step%d_times prev ON e.session_id = prev.session_id AND e.timestamp > prev.step_time
+			WHERE e.festival_id = ? AND e.type = ? AND e.timestamp >= ? AND e.timestamp <= ?
+			GROUP BY e.session_id
+		)`, i, i-1)
+		args = append(args, festivalID, steps[i], start, end)
+	}
+
+	query += fmt.Sprintf(`
+		SELECT r.session_id, r.step_time AS reached_at,
+		       (SELECT u.user_id FROM public.analytics_events u
+		        WHERE u.festival_id = ? AND u.session_id = r.session_id AND u.user_id IS NOT NULL
+		        LIMIT 1) AS user_id,
+		       last.timestamp AS last_event_at, last.type AS last_event_type,
+		       (SELECT COUNT(*) FROM public.analytics_events a
+		        WHERE a.festival_id = ? AND a.session_id = r.session_id AND a.timestamp > r.step_time) AS events_after
+		FROM step%d_times r
+		CROSS JOIN LATERAL (
+			SELECT l.timestamp, l.type FROM public.analytics_events l
+			WHERE l.festival_id = ? AND l.session_id = r.session_id
+			ORDER BY l.timestamp DESC, l.created_at DESC
+			LIMIT 1
+		) last
+		WHERE NOT EXISTS (
+			SELECT 1 FROM public.analytics_events n
+			WHERE n.festival_id = ? AND n.session_id = r.session_id AND n.type = ? AND n.timestamp > r.step_time
+		)
+		ORDER BY r.step_time DESC
+		LIMIT ?`, step-1)
+	args = append(args, festivalID, festivalID, festivalID, festivalID, steps[step], limit)
+
+	var sessions []DroppedSession
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get funnel drop-offs: %w", err)
+	}
+	return sessions, nil
+}
+
 // GetAnalyticsSummary retrieves a summary of analytics
 func (r *analyticsRepository) GetAnalyticsSummary(ctx context.Context, festivalID uuid.UUID, start, end time.Time) (*AnalyticsSummary, error) {
 	summary := &AnalyticsSummary{
@@ -139,6 +139,20 @@ func (s *AnalyticsService) GetFunnelAnalysis(ctx context.Context, festivalID uui
 		return nil, err
 	}
 
+	steps := s.funnelSteps(ctx, festivalID, funnelName)
+
+	funnel, err := s.repo.GetFunnelData(ctx, festivalID, steps, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funnel data: %w", err)
+	}
+
+	funnel.Name = funnelName
+	return funnel, nil
+}
+
+// funnelSteps returns the steps of a predefined or custom funnel, defaulting to the
+// ticket purchase funnel
+func (s *AnalyticsService) funnelSteps(ctx context.Context, festivalID uuid.UUID, funnelName string) []EventType {
 	// Get predefined funnel steps
 	var steps []EventType
 	for _, pf := range PredefinedFunnels() {
@@ -165,14 +179,41 @@ func (s *AnalyticsService) GetFunnelAnalysis(ctx context.Context, festivalID uui
 	if len(steps) == 0 {
 		steps = []EventType{EventTypePageView, EventTypeTicketView, EventTypeTicketBuy}
 	}
+	return steps
+}
 
-	funnel, err := s.repo.GetFunnelData(ctx, festivalID, steps, start, end)
+// GetFunnelDropOffs lists the sessions that completed the steps of a funnel up to
+// step-1 and never step, the last drop-offs first
+func (s *AnalyticsService) GetFunnelDropOffs(ctx context.Context, festivalID uuid.UUID, funnelName string, step int, start, end time.Time, limit int) (*FunnelDropOffs, error) {
+	if err := s.verifyFestival(ctx, festivalID); err != nil {
+		return nil, err
+	}
+
+	steps := s.funnelSteps(ctx, festivalID, funnelName)
+	if step < 1 || step >= len(steps) {
+		return nil, ErrFunnelStepOutOfRange
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	sessions, err := s.repo.GetFunnelDropOffs(ctx, festivalID, steps, step, start, end, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get funnel data: %w", err)
+		return nil, fmt.Errorf("failed to get funnel drop-offs: %w", err)
+	}
+	if sessions == nil {
+		sessions = []DroppedSession{}
 	}
 
-	funnel.Name = funnelName
-	return funnel, nil
+	return &FunnelDropOffs{
+		Funnel:   funnelName,
+		Step:     step,
+		From:     string(steps[step-1]),
+		To:       string(steps[step]),
+		Start:    start,
+		End:      end,
+		Sessions: sessions,
+	}, nil
 }
 
 // GetAllFunnels returns all available funnels with their analysis
@@ -332,6 +373,66 @@ func (s *AnalyticsService) GetUserJourney(ctx context.Context, userID, festivalI
 	return journey, nil
 }
 
+// GetSessionTimeline rebuilds the timeline of a session from its events and the orders
+// of its user. When funnelName is set, the events completing the funnel steps are
+// marked and the step the session dropped off at is reported.
+func (s *AnalyticsService) GetSessionTimeline(ctx context.Context, festivalID uuid.UUID, sessionID, funnelName string) (*SessionTimeline, error) {
+	if err := s.verifyFestival(ctx, festivalID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.GetEvents(ctx, festivalID, EventFilters{
+		SessionID:   sessionID,
+		Limit:       MaxTimelineEvents + 1,
+		OldestFirst: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session events: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	truncated := len(events) > MaxTimelineEvents
+	if truncated {
+		events = events[:MaxTimelineEvents]
+	}
+
+	var userID *uuid.UUID
+	for _, event := range events {
+		if event.UserID != nil {
+			userID = event.UserID
+			break
+		}
+	}
+
+	var orders []TimelineOrder
+	if userID != nil {
+		from := events[0].Timestamp
+		to := events[len(events)-1].Timestamp.Add(TimelineOrderMargin)
+		orders, err = s.repo.GetUserOrders(ctx, festivalID, *userID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session orders: %w", err)
+		}
+	}
+
+	entities, err := s.repo.GetTimelineEntities(ctx, festivalID, entityRefs(events, orders))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline entities: %w", err)
+	}
+
+	var funnel *TimelineFunnel
+	if funnelName != "" {
+		funnel = &TimelineFunnel{Name: funnelName}
+		for _, step := range s.funnelSteps(ctx, festivalID, funnelName) {
+			funnel.Steps = append(funnel.Steps, string(step))
+		}
+	}
+
+	timeline := buildSessionTimeline(festivalID, sessionID, events, orders, entities, funnel)
+	timeline.Truncated = truncated
+	return timeline, nil
+}
+
 // ==================== Predictions ====================
 
 // GetPredictions generates ML-based predictions for a festival
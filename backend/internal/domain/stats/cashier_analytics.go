@@ -0,0 +1,210 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CashierAnomaly flags a cashier metric that deviates from the cashier's peers
+type CashierAnomaly string
+
+const (
+	AnomalyHighRefundRatio     CashierAnomaly = "HIGH_REFUND_RATIO"
+	AnomalyHighVoidRate        CashierAnomaly = "HIGH_VOID_RATE"
+	AnomalyUnusualOrderValue   CashierAnomaly = "UNUSUAL_ORDER_VALUE"
+	AnomalyHighTransactionPace CashierAnomaly = "HIGH_TRANSACTION_PACE"
+)
+
+// CashierAnomalyConfig configures when cashier metrics are flagged as anomalies
+type CashierAnomalyConfig struct {
+	MinTransactions  int     // Cashiers with fewer sales and voids are never flagged
+	PeerMultiplier   float64 // Flag ratios above this multiple of the peer median
+	MinRefundRatio   float64 // Refund ratios below this are never flagged
+	MinVoidRate      float64 // Void rates below this are never flagged
+	OrderValueSpread float64 // Flag average order values outside median/spread..median*spread
+}
+
+// DefaultCashierAnomalyConfig returns the default cashier anomaly configuration
+func DefaultCashierAnomalyConfig() CashierAnomalyConfig {
+	return CashierAnomalyConfig{
+		MinTransactions:  20,
+		PeerMultiplier:   2,
+		MinRefundRatio:   0.05,
+		MinVoidRate:      0.05,
+		OrderValueSpread: 2,
+	}
+}
+
+// CashierActivity is the raw activity of one staff member, as aggregated by the repository
+type CashierActivity struct {
+	StaffID        uuid.UUID `gorm:"column:staff_id"`
+	StaffName      string    `gorm:"column:staff_name"`
+	Purchases      int       `gorm:"column:purchases"`
+	PurchaseAmount int64     `gorm:"column:purchase_amount"`
+	Refunds        int       `gorm:"column:refunds"`
+	RefundAmount   int64     `gorm:"column:refund_amount"`
+	TopUps         int       `gorm:"column:top_ups"`
+	TopUpAmount    int64     `gorm:"column:top_up_amount"`
+	Voids          int       `gorm:"column:voids"`        // Orders cancelled or voided by the staff member
+	ActiveHours    int       `gorm:"column:active_hours"` // Distinct clock hours with at least one transaction
+}
+
+// CashierPerformance holds the per-cashier analytics shown to stand managers
+type CashierPerformance struct {
+	StaffID             uuid.UUID        `json:"staffId"`
+	StaffName           string           `json:"staffName"`
+	Transactions        int              `json:"transactions"`
+	Purchases           int              `json:"purchases"`
+	PurchaseAmount      int64            `json:"purchaseAmount"`
+	PurchaseDisplay     string           `json:"purchaseAmountDisplay"`
+	Refunds             int              `json:"refunds"`
+	RefundAmount        int64            `json:"refundAmount"`
+	TopUps              int              `json:"topUps"`
+	TopUpAmount         int64            `json:"topUpAmount"`
+	Voids               int              `json:"voids"`
+	ActiveHours         int              `json:"activeHours"`
+	TransactionsPerHour float64          `json:"transactionsPerHour"`
+	AverageOrderValue   int64            `json:"averageOrderValue"`
+	RefundRatio         float64          `json:"refundRatio"` // Refunds per purchase
+	VoidRate            float64          `json:"voidRate"`    // Share of orders that were voided
+	Anomalies           []CashierAnomaly `json:"anomalies"`
+}
+
+// CashierAnalytics is the per-cashier analytics of a festival or stand
+type CashierAnalytics struct {
+	FestivalID                uuid.UUID            `json:"festivalId"`
+	StandID                   *uuid.UUID           `json:"standId,omitempty"`
+	Timeframe                 string               `json:"timeframe"`
+	MedianRefundRatio         float64              `json:"medianRefundRatio"`
+	MedianVoidRate            float64              `json:"medianVoidRate"`
+	MedianOrderValue          int64                `json:"medianOrderValue"`
+	MedianTransactionsPerHour float64              `json:"medianTransactionsPerHour"`
+	FlaggedCashiers           int                  `json:"flaggedCashiers"`
+	Cashiers                  []CashierPerformance `json:"cashiers"`
+	GeneratedAt               time.Time            `json:"generatedAt"`
+}
+
+// ComputeCashierAnalytics derives the per-hour pace, average order value, refund ratio
+// and void rate of every cashier and flags the ones that stand out from their peers.
+// Peers are the other cashiers in the same result set, so a stand-level request compares
+// cashiers of the same stand. Flagged cashiers are listed first.
+func ComputeCashierAnalytics(festivalID uuid.UUID, standID *uuid.UUID, timeframe Timeframe, activity []CashierActivity, cfg CashierAnomalyConfig, now time.Time) *CashierAnalytics {
+	cashiers := make([]CashierPerformance, len(activity))
+	for i, a := range activity {
+		c := CashierPerformance{
+			StaffID:         a.StaffID,
+			StaffName:       a.StaffName,
+			Transactions:    a.Purchases + a.Refunds + a.TopUps,
+			Purchases:       a.Purchases,
+			PurchaseAmount:  a.PurchaseAmount,
+			PurchaseDisplay: formatCurrency(a.PurchaseAmount),
+			Refunds:         a.Refunds,
+			RefundAmount:    a.RefundAmount,
+			TopUps:          a.TopUps,
+			TopUpAmount:     a.TopUpAmount,
+			Voids:           a.Voids,
+			ActiveHours:     a.ActiveHours,
+			Anomalies:       []CashierAnomaly{},
+		}
+		if a.ActiveHours > 0 {
+			c.TransactionsPerHour = roundTo(float64(c.Transactions)/float64(a.ActiveHours), 1)
+		}
+		if a.Purchases > 0 {
+			c.AverageOrderValue = a.PurchaseAmount / int64(a.Purchases)
+			c.RefundRatio = roundTo(float64(a.Refunds)/float64(a.Purchases), 3)
+		}
+		if a.Purchases+a.Voids > 0 {
+			c.VoidRate = roundTo(float64(a.Voids)/float64(a.Purchases+a.Voids), 3)
+		}
+		cashiers[i] = c
+	}
+
+	analytics := &CashierAnalytics{
+		FestivalID:  festivalID,
+		StandID:     standID,
+		Timeframe:   string(timeframe),
+		Cashiers:    cashiers,
+		GeneratedAt: now,
+	}
+
+	// Medians are taken over cashiers with enough activity to be meaningful
+	var refundRatios, voidRates, orderValues, paces []float64
+	for _, c := range cashiers {
+		if c.Purchases+c.Voids < cfg.MinTransactions {
+			continue
+		}
+		refundRatios = append(refundRatios, c.RefundRatio)
+		voidRates = append(voidRates, c.VoidRate)
+		if c.Purchases > 0 {
+			orderValues = append(orderValues, float64(c.AverageOrderValue))
+		}
+		if c.ActiveHours > 0 {
+			paces = append(paces, c.TransactionsPerHour)
+		}
+	}
+	analytics.MedianRefundRatio = roundTo(median(refundRatios), 3)
+	analytics.MedianVoidRate = roundTo(median(voidRates), 3)
+	analytics.MedianOrderValue = int64(median(orderValues))
+	analytics.MedianTransactionsPerHour = roundTo(median(paces), 1)
+
+	for i := range cashiers {
+		c := &cashiers[i]
+		if c.Purchases+c.Voids < cfg.MinTransactions {
+			continue
+		}
+		if c.RefundRatio >= cfg.MinRefundRatio && c.RefundRatio > analytics.MedianRefundRatio*cfg.PeerMultiplier {
+			c.Anomalies = append(c.Anomalies, AnomalyHighRefundRatio)
+		}
+		if c.VoidRate >= cfg.MinVoidRate && c.VoidRate > analytics.MedianVoidRate*cfg.PeerMultiplier {
+			c.Anomalies = append(c.Anomalies, AnomalyHighVoidRate)
+		}
+		if len(orderValues) > 1 && c.Purchases > 0 && cfg.OrderValueSpread > 1 {
+			value := float64(c.AverageOrderValue)
+			med := float64(analytics.MedianOrderValue)
+			if value < med/cfg.OrderValueSpread || value > med*cfg.OrderValueSpread {
+				c.Anomalies = append(c.Anomalies, AnomalyUnusualOrderValue)
+			}
+		}
+		// A pace far above peers can mean transactions are being keyed without customers
+		if len(paces) > 1 && analytics.MedianTransactionsPerHour > 0 &&
+			c.TransactionsPerHour > analytics.MedianTransactionsPerHour*cfg.PeerMultiplier {
+			c.Anomalies = append(c.Anomalies, AnomalyHighTransactionPace)
+		}
+		if len(c.Anomalies) > 0 {
+			analytics.FlaggedCashiers++
+		}
+	}
+
+	sort.SliceStable(cashiers, func(i, j int) bool {
+		if len(cashiers[i].Anomalies) != len(cashiers[j].Anomalies) {
+			return len(cashiers[i].Anomalies) > len(cashiers[j].Anomalies)
+		}
+		return cashiers[i].PurchaseAmount > cashiers[j].PurchaseAmount
+	})
+
+	return analytics
+}
+
+// median returns the median of the values, or 0 for an empty slice
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// roundTo rounds a value to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(value*p) / p
+}
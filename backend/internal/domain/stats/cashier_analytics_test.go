@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cashierActivity returns the activity of five cashiers with enough transactions to
+// be compared and one below the default MinTransactions:
+//
+//	name    purchases  avg value  refunds  voids  hours
+//	Alice   100        10.00      2        2      10
+//	Bob     100        11.00      3        3      10
+//	Chloe   100        10.50      20       2      12    refunds 10x the median
+//	Dan     100        9.50       2        30     10    voids 10x the median
+//	Eve     100        30.00      2        2      3     3x the order value, 3x the pace
+//	Finn    5          10.00      5        10     1     only 15 sales and voids
+func cashierActivity() []CashierActivity {
+	cashier := func(name string, purchases int, amount int64, refunds, voids, hours int) CashierActivity {
+		return CashierActivity{
+			StaffID:        uuid.New(),
+			StaffName:      name,
+			Purchases:      purchases,
+			PurchaseAmount: amount,
+			Refunds:        refunds,
+			Voids:          voids,
+			ActiveHours:    hours,
+		}
+	}
+	return []CashierActivity{
+		cashier("Alice", 100, 100000, 2, 2, 10),
+		cashier("Bob", 100, 110000, 3, 3, 10),
+		cashier("Chloe", 100, 105000, 20, 2, 12),
+		cashier("Dan", 100, 95000, 2, 30, 10),
+		cashier("Eve", 100, 300000, 2, 2, 3),
+		cashier("Finn", 5, 5000, 5, 10, 1),
+	}
+}
+
+func cashiersByName(analytics *CashierAnalytics) map[string]CashierPerformance {
+	byName := make(map[string]CashierPerformance, len(analytics.Cashiers))
+	for _, c := range analytics.Cashiers {
+		byName[c.StaffName] = c
+	}
+	return byName
+}
+
+func TestComputeCashierAnalytics(t *testing.T) {
+	now := time.Date(2026, 7, 18, 23, 0, 0, 0, time.UTC)
+	analytics := ComputeCashierAnalytics(uuid.New(), nil, TimeframeToday, cashierActivity(), DefaultCashierAnomalyConfig(), now)
+
+	// Medians over the five cashiers above the cutoff (odd count)
+	assert.Equal(t, 0.02, analytics.MedianRefundRatio)
+	assert.Equal(t, 0.02, analytics.MedianVoidRate)
+	assert.Equal(t, int64(1050), analytics.MedianOrderValue)
+	assert.Equal(t, 10.2, analytics.MedianTransactionsPerHour)
+	assert.Equal(t, 3, analytics.FlaggedCashiers)
+
+	cashiers := cashiersByName(analytics)
+	assert.Equal(t, []CashierAnomaly{AnomalyHighRefundRatio}, cashiers["Chloe"].Anomalies)
+	assert.Equal(t, []CashierAnomaly{AnomalyHighVoidRate}, cashiers["Dan"].Anomalies)
+	assert.Equal(t, []CashierAnomaly{AnomalyUnusualOrderValue, AnomalyHighTransactionPace}, cashiers["Eve"].Anomalies)
+	assert.Empty(t, cashiers["Alice"].Anomalies)
+	assert.Empty(t, cashiers["Bob"].Anomalies)
+
+	// Below MinTransactions: never flagged, however high its refund ratio
+	assert.Equal(t, 1.0, cashiers["Finn"].RefundRatio)
+	assert.Empty(t, cashiers["Finn"].Anomalies)
+
+	eve := cashiers["Eve"]
+	assert.Equal(t, 102, eve.Transactions) // Purchases and refunds; voids are not transactions
+	assert.Equal(t, 34.0, eve.TransactionsPerHour)
+	assert.Equal(t, int64(3000), eve.AverageOrderValue)
+	assert.Equal(t, 0.231, cashiers["Dan"].VoidRate)
+
+	// Most anomalies first, then by sales
+	var order []string
+	for _, c := range analytics.Cashiers {
+		order = append(order, c.StaffName)
+	}
+	assert.Equal(t, []string{"Eve", "Chloe", "Dan", "Bob", "Alice", "Finn"}, order)
+}
+
+func TestComputeCashierAnalytics_MinTransactions(t *testing.T) {
+	cfg := DefaultCashierAnomalyConfig()
+	cfg.MinTransactions = 15
+	analytics := ComputeCashierAnalytics(uuid.New(), nil, TimeframeToday, cashierActivity(), cfg, time.Now())
+
+	// Finn now counts: flagged and part of the medians (even count of six)
+	cashiers := cashiersByName(analytics)
+	assert.Contains(t, cashiers["Finn"].Anomalies, AnomalyHighRefundRatio)
+	assert.Equal(t, 0.025, analytics.MedianRefundRatio)
+}
+
+func TestComputeCashierAnalytics_EvenPeers(t *testing.T) {
+	activity := cashierActivity()[:4]
+	analytics := ComputeCashierAnalytics(uuid.New(), nil, TimeframeToday, activity, DefaultCashierAnomalyConfig(), time.Now())
+
+	// Averages of the two middle values
+	assert.Equal(t, int64(1025), analytics.MedianOrderValue)
+	assert.Equal(t, 10.2, analytics.MedianTransactionsPerHour)
+	assert.Equal(t, 0.025, analytics.MedianRefundRatio)
+	assert.Equal(t, 0.025, analytics.MedianVoidRate)
+}
+
+func TestComputeCashierAnalytics_SinglePeer(t *testing.T) {
+	activity := cashierActivity()[4:5]
+	analytics := ComputeCashierAnalytics(uuid.New(), nil, TimeframeToday, activity, DefaultCashierAnomalyConfig(), time.Now())
+
+	// Order value and pace need peers to compare with
+	require.Len(t, analytics.Cashiers, 1)
+	assert.Empty(t, analytics.Cashiers[0].Anomalies)
+	assert.Zero(t, analytics.FlaggedCashiers)
+}
+
+func TestMedian(t *testing.T) {
+	assert.Equal(t, 0.0, median(nil))
+	assert.Equal(t, 2.0, median([]float64{3, 1, 2}))
+	assert.Equal(t, 2.5, median([]float64{4, 1, 3, 2}))
+
+	values := []float64{3, 1, 2}
+	median(values)
+	assert.Equal(t, []float64{3, 1, 2}, values, "input left unsorted")
+}
@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// Task type for the dashboard aggregate refresh job
+const TypeMaterializeDashboard = "analytics:materialize_dashboard"
+
+// MaterializeDashboardPayload limits the refresh to one festival; all festivals with
+// recent sales otherwise. Rebuild recomputes every bucket instead of the recent ones.
+type MaterializeDashboardPayload struct {
+	FestivalID *uuid.UUID `json:"festivalId,omitempty"`
+	Rebuild    bool       `json:"rebuild,omitempty"`
+}
+
+// NewMaterializeDashboardTask creates a task that refreshes the dashboard aggregates
+func NewMaterializeDashboardTask(payload MaterializeDashboardPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeMaterializeDashboard, data), nil
+}
+
+// DashboardAggregateConfig configures the materialized dashboard aggregates
+type DashboardAggregateConfig struct {
+	Lag        time.Duration // Recent window recomputed on every refresh, covering transactions committed late
+	StaleAfter time.Duration // Dashboards fall back to raw queries when the aggregates are older than this
+	Lookback   time.Duration // Festivals with sales in this window are refreshed by the periodic job
+}
+
+// DefaultDashboardAggregateConfig returns the default dashboard aggregate configuration
+func DefaultDashboardAggregateConfig() DashboardAggregateConfig {
+	return DashboardAggregateConfig{
+		Lag:        2 * time.Minute,
+		StaleAfter: 5 * time.Minute,
+		Lookback:   time.Hour,
+	}
+}
+
+// MaterializeDashboard brings the dashboard aggregates of a festival up to date. It only
+// recomputes the buckets touched since the previous refresh, so it is cheap enough to
+// run on every analytics event.
+func (s *Service) MaterializeDashboard(ctx context.Context, festivalID uuid.UUID) error {
+	return s.repo.MaterializeDashboardAggregates(ctx, festivalID, time.Now(), s.aggregates.Lag, false)
+}
+
+// dashboardAggregatesFresh reports whether the dashboard of a festival can be served
+// from the aggregates
+func (s *Service) dashboardAggregatesFresh(ctx context.Context, festivalID uuid.UUID) bool {
+	watermark, err := s.repo.GetDashboardWatermark(ctx, festivalID)
+	if err != nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to get dashboard watermark")
+		return false
+	}
+	return watermark != nil && time.Since(*watermark) <= s.aggregates.StaleAfter
+}
+
+// HandleMaterializeDashboard processes the periodic dashboard aggregate refresh. Event
+// driven refreshes keep active festivals current; this catches up the rest and, with
+// Rebuild, picks up orders voided after their buckets were materialized.
+func (s *Service) HandleMaterializeDashboard(ctx context.Context, t *asynq.Task) error {
+	var payload MaterializeDashboardPayload
+	if len(t.Payload()) > 0 {
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+
+	festivalIDs := []uuid.UUID{}
+	if payload.FestivalID != nil {
+		festivalIDs = append(festivalIDs, *payload.FestivalID)
+	} else {
+		lookback := s.aggregates.Lookback
+		if payload.Rebuild {
+			lookback = 24 * time.Hour
+		}
+		ids, err := s.repo.GetFestivalsWithSales(ctx, time.Now().Add(-lookback))
+		if err != nil {
+			return err
+		}
+		festivalIDs = ids
+	}
+
+	for _, festivalID := range festivalIDs {
+		start := time.Now()
+		if err := s.repo.MaterializeDashboardAggregates(ctx, festivalID, start, s.aggregates.Lag, payload.Rebuild); err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to materialize dashboard aggregates")
+			continue
+		}
+		log.Debug().
+			Str("festival_id", festivalID.String()).
+			Bool("rebuild", payload.Rebuild).
+			Dur("duration", time.Since(start)).
+			Msg("Materialized dashboard aggregates")
+	}
+
+	return nil
+}
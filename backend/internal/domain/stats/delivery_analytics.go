@@ -0,0 +1,191 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryRecord is one order delivered to a table or pitch, or still to deliver, as
+// retrieved by the repository
+type DeliveryRecord struct {
+	LocationID    uuid.UUID  `gorm:"column:location_id"`
+	LocationLabel string     `gorm:"column:location_label"`
+	Zone          string     `gorm:"column:zone"`
+	RunnerID      *uuid.UUID `gorm:"column:runner_id"`
+	RunnerName    string     `gorm:"column:runner_name"`
+	OrderedAt     time.Time  `gorm:"column:ordered_at"`
+	DueAt         time.Time  `gorm:"column:due_at"`
+	AssignedAt    *time.Time `gorm:"column:assigned_at"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at"`
+}
+
+// DeliverySLA holds the delivery times and SLA compliance of a group of deliveries
+type DeliverySLA struct {
+	Key              string  `json:"key,omitempty"`   // Zone, location ID or runner ID
+	Label            string  `json:"label,omitempty"` // Zone, location label or runner name
+	Orders           int     `json:"orders"`
+	Delivered        int     `json:"delivered"`
+	OnTime           int     `json:"onTime"`
+	Late             int     `json:"late"`       // Delivered after the deadline
+	Overdue          int     `json:"overdue"`    // Not delivered and past the deadline
+	OnTimeRate       float64 `json:"onTimeRate"` // Share of delivered orders delivered in time
+	AvgMinutes       float64 `json:"avgMinutes"` // Order to delivery
+	P90Minutes       float64 `json:"p90Minutes"`
+	AvgAssignMinutes float64 `json:"avgAssignMinutes"` // Order to a runner taking it
+}
+
+// DeliveryAnalytics is the SLA compliance of the table and pitch deliveries of a festival
+type DeliveryAnalytics struct {
+	FestivalID  uuid.UUID     `json:"festivalId"`
+	Timeframe   string        `json:"timeframe"`
+	Totals      DeliverySLA   `json:"totals"`
+	ByZone      []DeliverySLA `json:"byZone"`
+	ByLocation  []DeliverySLA `json:"byLocation"`
+	ByRunner    []DeliverySLA `json:"byRunner"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+}
+
+// ComputeDeliveryAnalytics measures the delivery times of the orders against their
+// deadline, for the festival and by zone, location and runner. Orders not delivered yet
+// only count once their deadline has passed. Groups with the lowest on-time rate are
+// listed first.
+func ComputeDeliveryAnalytics(festivalID uuid.UUID, timeframe Timeframe, records []DeliveryRecord, now time.Time) *DeliveryAnalytics {
+	zones := make(map[string]*deliveryGroup)
+	locations := make(map[string]*deliveryGroup)
+	runners := make(map[string]*deliveryGroup)
+	total := &deliveryGroup{}
+
+	for _, r := range records {
+		zone := r.Zone
+		if zone == "" {
+			zone = "No zone"
+		}
+		groups := []*deliveryGroup{
+			total,
+			groupFor(zones, zone, zone),
+			groupFor(locations, r.LocationID.String(), r.LocationLabel),
+		}
+		if r.RunnerID != nil {
+			groups = append(groups, groupFor(runners, r.RunnerID.String(), r.RunnerName))
+		}
+		for _, g := range groups {
+			g.add(r, now)
+		}
+	}
+
+	return &DeliveryAnalytics{
+		FestivalID:  festivalID,
+		Timeframe:   string(timeframe),
+		Totals:      total.sla(),
+		ByZone:      sortedSLAs(zones),
+		ByLocation:  sortedSLAs(locations),
+		ByRunner:    sortedSLAs(runners),
+		GeneratedAt: now,
+	}
+}
+
+// deliveryGroup accumulates the deliveries of a group
+type deliveryGroup struct {
+	key, label    string
+	orders        int
+	onTime, late  int
+	overdue       int
+	minutes       []float64
+	assignMinutes []float64
+}
+
+func groupFor(groups map[string]*deliveryGroup, key, label string) *deliveryGroup {
+	g, ok := groups[key]
+	if !ok {
+		g = &deliveryGroup{key: key, label: label}
+		groups[key] = g
+	}
+	return g
+}
+
+func (g *deliveryGroup) add(r DeliveryRecord, now time.Time) {
+	if r.AssignedAt != nil {
+		g.assignMinutes = append(g.assignMinutes, r.AssignedAt.Sub(r.OrderedAt).Minutes())
+	}
+	if r.DeliveredAt == nil {
+		if now.After(r.DueAt) {
+			g.orders++
+			g.overdue++
+		}
+		return
+	}
+
+	g.orders++
+	g.minutes = append(g.minutes, r.DeliveredAt.Sub(r.OrderedAt).Minutes())
+	if r.DeliveredAt.After(r.DueAt) {
+		g.late++
+	} else {
+		g.onTime++
+	}
+}
+
+func (g *deliveryGroup) sla() DeliverySLA {
+	sla := DeliverySLA{
+		Key:              g.key,
+		Label:            g.label,
+		Orders:           g.orders,
+		Delivered:        len(g.minutes),
+		OnTime:           g.onTime,
+		Late:             g.late,
+		Overdue:          g.overdue,
+		AvgMinutes:       roundTo(mean(g.minutes), 1),
+		P90Minutes:       roundTo(percentile(g.minutes, 0.9), 1),
+		AvgAssignMinutes: roundTo(mean(g.assignMinutes), 1),
+	}
+	if sla.Delivered > 0 {
+		sla.OnTimeRate = roundTo(float64(sla.OnTime)/float64(sla.Delivered), 3)
+	}
+	return sla
+}
+
+func sortedSLAs(groups map[string]*deliveryGroup) []DeliverySLA {
+	slas := make([]DeliverySLA, 0, len(groups))
+	for _, g := range groups {
+		slas = append(slas, g.sla())
+	}
+	sort.Slice(slas, func(i, j int) bool {
+		if slas[i].OnTimeRate != slas[j].OnTimeRate {
+			return slas[i].OnTimeRate < slas[j].OnTimeRate
+		}
+		if slas[i].Orders != slas[j].Orders {
+			return slas[i].Orders > slas[j].Orders
+		}
+		return slas[i].Label < slas[j].Label
+	})
+	return slas
+}
+
+// mean returns the mean of the values, or 0 for an empty slice
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// percentile returns the nearest-rank percentile p of the values, or 0 for an empty slice
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
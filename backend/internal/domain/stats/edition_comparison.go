@@ -0,0 +1,324 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+)
+
+// ComparisonAlignment defines how the days of two editions are lined up
+type ComparisonAlignment string
+
+const (
+	AlignFestivalDay ComparisonAlignment = "DAY"     // Day N of an edition against day N of the other
+	AlignWeekday     ComparisonAlignment = "WEEKDAY" // Friday against Friday, for editions that moved in the week
+)
+
+// ParseComparisonAlignment converts a string to a ComparisonAlignment, defaulting to AlignFestivalDay
+func ParseComparisonAlignment(s string) (ComparisonAlignment, bool) {
+	switch ComparisonAlignment(strings.ToUpper(s)) {
+	case "", AlignFestivalDay:
+		return AlignFestivalDay, true
+	case AlignWeekday:
+		return AlignWeekday, true
+	}
+	return "", false
+}
+
+const (
+	// DefaultComparedEditions is how many previous editions are followed when none are requested
+	DefaultComparedEditions = 3
+	// MaxComparedEditions caps the editions one comparison can request
+	MaxComparedEditions = 5
+)
+
+// Edition is a festival as seen by an edition comparison
+type Edition struct {
+	ID                uuid.UUID  `gorm:"column:id"`
+	Name              string     `gorm:"column:name"`
+	StartDate         time.Time  `gorm:"column:start_date"`
+	EndDate           time.Time  `gorm:"column:end_date"`
+	Timezone          string     `gorm:"column:timezone"`
+	PreviousEditionID *uuid.UUID `gorm:"column:previous_edition_id"`
+}
+
+// Location returns the timezone of the edition
+func (e *Edition) Location() *time.Location {
+	return tz.Load(e.Timezone)
+}
+
+// Day returns the start of a day of the edition in its timezone, day 0 being its start date
+func (e *Edition) Day(index int) time.Time {
+	return time.Date(e.StartDate.Year(), e.StartDate.Month(), e.StartDate.Day()+index, 0, 0, 0, 0, e.Location())
+}
+
+// Days returns the number of calendar days of the edition
+func (e *Edition) Days() int {
+	start := time.Date(e.StartDate.Year(), e.StartDate.Month(), e.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(e.EndDate.Year(), e.EndDate.Month(), e.EndDate.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(end.Sub(start).Hours()/24) + 1
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// Window returns the start of the first day and the end of the last day of the edition
+func (e *Edition) Window() (time.Time, time.Time) {
+	return e.Day(0), e.Day(e.Days())
+}
+
+// EditionHourlySales is the purchase revenue of an edition in one local hour
+type EditionHourlySales struct {
+	Date         time.Time `gorm:"column:local_date"` // Date in the festival timezone
+	Hour         int       `gorm:"column:local_hour"`
+	Revenue      int64     `gorm:"column:revenue"`
+	Transactions int       `gorm:"column:transactions"`
+}
+
+// EditionHourlyEntries is the number of attendees entering an edition for the first time
+// of their day in one local hour
+type EditionHourlyEntries struct {
+	Date    time.Time `gorm:"column:local_date"` // Date in the festival timezone
+	Hour    int       `gorm:"column:local_hour"`
+	Entries int64     `gorm:"column:entries"`
+}
+
+// EditionData is an edition with its hourly sales and entries
+type EditionData struct {
+	Edition Edition
+	Sales   []EditionHourlySales
+	Entries []EditionHourlyEntries
+}
+
+// EditionComparison lines up the sales of a festival with its previous editions by
+// relative day and hour ("Friday 21:00 against last year's Friday 21:00")
+type EditionComparison struct {
+	FestivalID  uuid.UUID           `json:"festivalId"`
+	Alignment   ComparisonAlignment `json:"alignment"`
+	AsOf        *ComparisonSlot     `json:"asOf,omitempty"` // Slot the to-date figures stop at, nil before the festival starts
+	Editions    []EditionSummary    `json:"editions"`       // Current edition first
+	Series      []EditionSeries     `json:"series"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+// ComparisonSlot is an hour of the current edition
+type ComparisonSlot struct {
+	Day   int    `json:"day"` // Day of the current edition, 0 being its first day
+	Hour  int    `json:"hour"`
+	Label string `json:"label"` // e.g. "Fri 21:00"
+}
+
+// EditionSummary sums up an edition. The to-date figures stop at the AsOf slot of the
+// comparison so an ongoing edition is compared with its predecessors at the same point.
+type EditionSummary struct {
+	FestivalID               uuid.UUID `json:"festivalId"`
+	Name                     string    `json:"name"`
+	StartDate                string    `json:"startDate"`
+	EndDate                  string    `json:"endDate"`
+	Current                  bool      `json:"current"`
+	DayOffset                int       `json:"dayOffset"` // Day of this edition lined up with day 0 of the current one
+	Revenue                  int64     `json:"revenue"`
+	RevenueDisplay           string    `json:"revenueDisplay"`
+	Transactions             int       `json:"transactions"`
+	AttendeeDays             int64     `json:"attendeeDays"`
+	RevenuePerAttendee       *int64    `json:"revenuePerAttendee,omitempty"` // Per attendee-day, nil without entries
+	RevenueToDate            int64     `json:"revenueToDate"`
+	AttendeeDaysToDate       int64     `json:"attendeeDaysToDate"`
+	RevenuePerAttendeeToDate *int64    `json:"revenuePerAttendeeToDate,omitempty"`
+	RevenueChangePercent     *float64  `json:"revenueChangePercent,omitempty"`     // Current edition against this one, to date
+	PerAttendeeChangePercent *float64  `json:"perAttendeeChangePercent,omitempty"` // Same, normalized by attendance
+}
+
+// EditionSeries is the chartable hourly series of an edition on the slots of the current one
+type EditionSeries struct {
+	FestivalID uuid.UUID            `json:"festivalId"`
+	Name       string               `json:"name"`
+	Points     []EditionSeriesPoint `json:"points"`
+}
+
+// EditionSeriesPoint is one hour of an edition series. Cumulative figures start at the
+// first slot of the current edition.
+type EditionSeriesPoint struct {
+	Day                int    `json:"day"`
+	Hour               int    `json:"hour"`
+	Label              string `json:"label"`
+	Revenue            int64  `json:"revenue"`
+	CumulativeRevenue  int64  `json:"cumulativeRevenue"`
+	Entries            int64  `json:"entries"`
+	AttendeeDays       int64  `json:"attendeeDays"`                 // Cumulative
+	RevenuePerAttendee *int64 `json:"revenuePerAttendee,omitempty"` // Cumulative revenue per attendee-day
+}
+
+// editionSlot keys hourly figures by local date and hour
+type editionSlot struct {
+	date string
+	hour int
+}
+
+// CompareEditions lines up the current edition with the previous ones. Previous days
+// falling outside the current edition count towards their totals but not their series.
+func CompareEditions(current EditionData, previous []EditionData, alignment ComparisonAlignment, now time.Time) *EditionComparison {
+	comparison := &EditionComparison{
+		FestivalID:  current.Edition.ID,
+		Alignment:   alignment,
+		Editions:    make([]EditionSummary, 0, len(previous)+1),
+		Series:      make([]EditionSeries, 0, len(previous)+1),
+		GeneratedAt: now,
+	}
+
+	days := current.Edition.Days()
+	asOf := currentSlot(&current.Edition, now)
+	if asOf >= 0 {
+		comparison.AsOf = &ComparisonSlot{
+			Day:   asOf / 24,
+			Hour:  asOf % 24,
+			Label: slotLabel(&current.Edition, asOf/24, asOf%24),
+		}
+	}
+
+	editions := append([]EditionData{current}, previous...)
+	var currentSummary EditionSummary
+	for i, data := range editions {
+		offset := 0
+		if i > 0 && alignment == AlignWeekday {
+			offset = weekdayOffset(&current.Edition, &data.Edition)
+		}
+
+		summary, series := compareEdition(&current.Edition, data, offset, days, asOf, i == 0)
+		if i == 0 {
+			currentSummary = summary
+		} else if asOf >= 0 {
+			summary.RevenueChangePercent = changePercent(currentSummary.RevenueToDate, summary.RevenueToDate)
+			if currentSummary.RevenuePerAttendeeToDate != nil && summary.RevenuePerAttendeeToDate != nil {
+				summary.PerAttendeeChangePercent = changePercent(*currentSummary.RevenuePerAttendeeToDate, *summary.RevenuePerAttendeeToDate)
+			}
+		}
+
+		comparison.Editions = append(comparison.Editions, summary)
+		comparison.Series = append(comparison.Series, series)
+	}
+
+	return comparison
+}
+
+// compareEdition builds the summary and series of one edition over the slots of the
+// current edition, up to asOf for the current edition itself
+func compareEdition(current *Edition, data EditionData, offset, days, asOf int, isCurrent bool) (EditionSummary, EditionSeries) {
+	edition := &data.Edition
+	summary := EditionSummary{
+		FestivalID: edition.ID,
+		Name:       edition.Name,
+		StartDate:  edition.StartDate.Format("2006-01-02"),
+		EndDate:    edition.EndDate.Format("2006-01-02"),
+		Current:    isCurrent,
+		DayOffset:  offset,
+	}
+	series := EditionSeries{
+		FestivalID: edition.ID,
+		Name:       edition.Name,
+		Points:     []EditionSeriesPoint{},
+	}
+
+	revenue := make(map[editionSlot]int64, len(data.Sales))
+	for _, s := range data.Sales {
+		revenue[editionSlot{s.Date.Format("2006-01-02"), s.Hour}] += s.Revenue
+		summary.Revenue += s.Revenue
+		summary.Transactions += s.Transactions
+	}
+	entries := make(map[editionSlot]int64, len(data.Entries))
+	for _, e := range data.Entries {
+		entries[editionSlot{e.Date.Format("2006-01-02"), e.Hour}] += e.Entries
+		summary.AttendeeDays += e.Entries
+	}
+	summary.RevenueDisplay = formatCurrency(summary.Revenue)
+	summary.RevenuePerAttendee = perAttendee(summary.Revenue, summary.AttendeeDays)
+
+	editionDays := edition.Days()
+	var cumulativeRevenue, attendeeDays int64
+	for slot := 0; slot < days*24; slot++ {
+		if isCurrent && slot > asOf {
+			break
+		}
+		day := slot/24 + offset
+		if day < 0 || day >= editionDays {
+			continue
+		}
+
+		key := editionSlot{edition.Day(day).Format("2006-01-02"), slot % 24}
+		cumulativeRevenue += revenue[key]
+		attendeeDays += entries[key]
+		series.Points = append(series.Points, EditionSeriesPoint{
+			Day:                slot / 24,
+			Hour:               slot % 24,
+			Label:              slotLabel(current, slot/24, slot%24),
+			Revenue:            revenue[key],
+			CumulativeRevenue:  cumulativeRevenue,
+			Entries:            entries[key],
+			AttendeeDays:       attendeeDays,
+			RevenuePerAttendee: perAttendee(cumulativeRevenue, attendeeDays),
+		})
+
+		if slot <= asOf {
+			summary.RevenueToDate = cumulativeRevenue
+			summary.AttendeeDaysToDate = attendeeDays
+		}
+	}
+	summary.RevenuePerAttendeeToDate = perAttendee(summary.RevenueToDate, summary.AttendeeDaysToDate)
+
+	return summary, series
+}
+
+// currentSlot returns the slot of the current edition at now, the last slot once the
+// edition is over and -1 before it starts
+func currentSlot(edition *Edition, now time.Time) int {
+	start, end := edition.Window()
+	switch {
+	case now.Before(start):
+		return -1
+	case !now.Before(end):
+		return edition.Days()*24 - 1
+	}
+
+	local := now.In(edition.Location())
+	for day := edition.Days() - 1; day >= 0; day-- {
+		if !local.Before(edition.Day(day)) {
+			return day*24 + local.Hour()
+		}
+	}
+	return -1
+}
+
+// weekdayOffset returns the day of the previous edition falling on the same weekday as
+// the first day of the current one, within three days of its start
+func weekdayOffset(current, previous *Edition) int {
+	shift := (int(current.StartDate.Weekday()) - int(previous.StartDate.Weekday()) + 7) % 7
+	if shift > 3 {
+		shift -= 7
+	}
+	return shift
+}
+
+func slotLabel(edition *Edition, day, hour int) string {
+	return fmt.Sprintf("%s %02d:00", edition.Day(day).Weekday().String()[:3], hour)
+}
+
+func perAttendee(revenue, attendeeDays int64) *int64 {
+	if attendeeDays == 0 {
+		return nil
+	}
+	value := revenue / attendeeDays
+	return &value
+}
+
+func changePercent(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	percent := math.Round(float64(current-previous)/float64(previous)*1000) / 10
+	return &percent
+}
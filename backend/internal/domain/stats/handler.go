@@ -2,6 +2,7 @@ package stats
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -30,15 +31,24 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		festivals.GET("/:id/stats/revenue", h.GetRevenueChart)
 		festivals.GET("/:id/stats/products", h.GetTopProducts)
 		festivals.GET("/:id/stats/staff", h.GetStaffPerformance)
+		festivals.GET("/:id/stats/cashiers", h.GetCashierAnalytics)
+		festivals.GET("/:id/stats/staffing", h.GetStaffingRecommendations)
+		festivals.GET("/:id/stats/deliveries", h.GetDeliveryAnalytics)
+		festivals.GET("/:id/stats/margins", h.GetMarginAnalytics)
+		festivals.GET("/:id/stats/margins/orders", h.ListOrderMargins)
 		festivals.GET("/:id/stats/transactions", h.GetRecentTransactions)
 		festivals.GET("/:id/stats/daily", h.GetDailyStats)
 		festivals.GET("/:id/stats/stands", h.GetTopStands)
+		festivals.GET("/:id/stats/categories", h.GetRevenueByCategory)
+		festivals.GET("/:id/stats/weather", h.GetWeatherImpact)
+		festivals.GET("/:id/stats/editions", h.GetEditionComparison)
 	}
 
 	// Stand stats routes
 	stands := r.Group("/stands")
 	{
 		stands.GET("/:id/stats", h.GetStandStats)
+		stands.GET("/:id/stats/cashiers", h.GetStandCashierAnalytics)
 	}
 }
 
@@ -114,8 +124,8 @@ func (h *Handler) GetDashboardStats(c *gin.Context) {
 // @Tags stats
 // @Produce json
 // @Param id path string true "Festival ID"
-// @Param start_date query string false "Start date (YYYY-MM-DD)" default(7 days ago)
-// @Param end_date query string false "End date (YYYY-MM-DD)" default(today)
+// @Param start_date query string false "Start date (YYYY-MM-DD) of an operational day of the festival" default(7 days ago)
+// @Param end_date query string false "End date (YYYY-MM-DD) of an operational day of the festival" default(today)
 // @Success 200 {object} RevenueChartData
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
@@ -127,8 +137,8 @@ func (h *Handler) GetRevenueChart(c *gin.Context) {
 		return
 	}
 
-	// Parse date range
-	endDate := time.Now()
+	// Parse date range, defaulting to the last week of operational days of the festival
+	endDate := h.service.Calendar(c.Request.Context(), festivalID).DateOf(time.Now())
 	startDate := endDate.AddDate(0, 0, -7)
 
 	if startStr := c.Query("start_date"); startStr != "" {
@@ -209,6 +219,34 @@ func (h *Handler) GetTopProducts(c *gin.Context) {
 	response.OK(c, products)
 }
 
+// GetRevenueByCategory returns revenue grouped by product category
+// @Summary Get revenue by category
+// @Description Get product revenue per festival category, with subcategory revenue rolled up into parents
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(TODAY)
+// @Success 200 {array} CategoryRevenue
+// @Failure 400 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/categories [get]
+func (h *Handler) GetRevenueByCategory(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "TODAY"))
+
+	categories, err := h.service.GetRevenueByCategory(c.Request.Context(), festivalID, timeframe)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, categories)
+}
+
 // GetStaffPerformance returns staff performance statistics
 // @Summary Get staff performance
 // @Description Get performance statistics for all staff members
@@ -242,6 +280,285 @@ func (h *Handler) GetStaffPerformance(c *gin.Context) {
 	response.OK(c, performance)
 }
 
+// GetWeatherImpact returns revenue correlated with the weather
+// @Summary Get weather impact
+// @Description Compare hourly revenue in wet and dry weather and estimate the weather-adjusted revenue
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(ALL)
+// @Success 200 {object} WeatherImpact
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/weather [get]
+func (h *Handler) GetWeatherImpact(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "ALL"))
+
+	impact, err := h.service.GetWeatherImpact(c.Request.Context(), festivalID, timeframe)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, impact)
+}
+
+// GetEditionComparison compares the festival with its previous editions
+// @Summary Compare festival editions
+// @Description Line up hourly sales with previous editions by relative day and hour, normalized by attendance, with chartable series
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param editions query string false "Comma-separated IDs of the editions to compare, defaults to the previous editions"
+// @Param align query string false "Day alignment (DAY, WEEKDAY)" default(DAY)
+// @Success 200 {object} EditionComparison
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/editions [get]
+func (h *Handler) GetEditionComparison(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	alignment, ok := ParseComparisonAlignment(c.Query("align"))
+	if !ok {
+		response.BadRequest(c, "INVALID_ALIGNMENT", "Alignment must be DAY or WEEKDAY", nil)
+		return
+	}
+
+	var editionIDs []uuid.UUID
+	if editions := c.Query("editions"); editions != "" {
+		for _, raw := range strings.Split(editions, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				response.BadRequest(c, "INVALID_ID", "Invalid edition ID", nil)
+				return
+			}
+			editionIDs = append(editionIDs, id)
+		}
+		if len(editionIDs) > MaxComparedEditions {
+			response.BadRequest(c, "TOO_MANY_EDITIONS", "Too many editions to compare", map[string]interface{}{
+				"max": MaxComparedEditions,
+			})
+			return
+		}
+	}
+
+	comparison, err := h.service.GetEditionComparison(c.Request.Context(), festivalID, editionIDs, alignment)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, comparison)
+}
+
+// GetCashierAnalytics returns per-cashier analytics with anomaly flags
+// @Summary Get cashier analytics
+// @Description Get transactions per hour, average order value, refund ratio and void rate per staff member, flagging cashiers that deviate from their peers
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(TODAY)
+// @Success 200 {object} CashierAnalytics
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/cashiers [get]
+func (h *Handler) GetCashierAnalytics(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "TODAY"))
+
+	analytics, err := h.service.GetCashierAnalytics(c.Request.Context(), festivalID, timeframe)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, analytics)
+}
+
+// GetDeliveryAnalytics returns the SLA compliance of table and pitch deliveries
+// @Summary Get delivery analytics
+// @Description Get the delivery times and on-time rate of orders delivered to tables and camping pitches, by zone, location and runner
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(TODAY)
+// @Success 200 {object} DeliveryAnalytics
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/deliveries [get]
+func (h *Handler) GetDeliveryAnalytics(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "TODAY"))
+
+	analytics, err := h.service.GetDeliveryAnalytics(c.Request.Context(), festivalID, timeframe)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, analytics)
+}
+
+// GetMarginAnalytics returns the gross margin of the festival and of each stand
+// @Summary Get margin analytics
+// @Description Get the revenue, cost of goods and gross margin of the paid orders, in total and by stand. Items sold before their product had a cost price are reported as uncosted revenue and left out of the margin.
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(TODAY)
+// @Success 200 {object} MarginAnalytics
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/margins [get]
+func (h *Handler) GetMarginAnalytics(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "TODAY"))
+
+	analytics, err := h.service.GetMarginAnalytics(c.Request.Context(), festivalID, timeframe)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, analytics)
+}
+
+// ListOrderMargins returns the gross margin of each paid order
+// @Summary List order margins
+// @Description List the revenue, cost of goods and gross margin of the paid orders, latest first
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param standId query string false "Only the orders of this stand" format(uuid)
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(TODAY)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]OrderMargin,meta=response.Meta}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/margins/orders [get]
+func (h *Handler) ListOrderMargins(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var standID *uuid.UUID
+	if raw := c.Query("standId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_STAND_ID", "Invalid stand ID", nil)
+			return
+		}
+		standID = &id
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "TODAY"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	margins, total, err := h.service.ListOrderMargins(c.Request.Context(), festivalID, standID, timeframe, (page-1)*perPage, perPage)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, margins, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// GetStaffingRecommendations returns surge staffing recommendations
+// @Summary Get staffing recommendations
+// @Description Get extra cashier recommendations per stand and time window, based on historical order volume and staffing
+// @Tags stats
+// @Produce json
+// @Param id path string true "Festival ID"
+// @Param refresh query bool false "Recompute the recommendations instead of returning the last daily run"
+// @Success 200 {array} StaffingRecommendationResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /festivals/{id}/stats/staffing [get]
+func (h *Handler) GetStaffingRecommendations(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	refresh := c.Query("refresh") == "true"
+
+	recommendations, err := h.service.GetStaffingRecommendations(c.Request.Context(), festivalID, refresh)
+	if err != nil {
+		if err == errors.ErrFestivalNotFound {
+			response.NotFound(c, "Festival not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, recommendations)
+}
+
 // GetRecentTransactions returns recent transactions
 // @Summary Get recent transactions
 // @Description Get recent transactions for a festival
@@ -281,8 +598,8 @@ func (h *Handler) GetRecentTransactions(c *gin.Context) {
 // @Tags stats
 // @Produce json
 // @Param id path string true "Festival ID"
-// @Param start_date query string false "Start date (YYYY-MM-DD)" default(7 days ago)
-// @Param end_date query string false "End date (YYYY-MM-DD)" default(today)
+// @Param start_date query string false "Start date (YYYY-MM-DD) of an operational day of the festival" default(7 days ago)
+// @Param end_date query string false "End date (YYYY-MM-DD) of an operational day of the festival" default(today)
 // @Success 200 {array} DailyStatsResponse
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
@@ -294,8 +611,8 @@ func (h *Handler) GetDailyStats(c *gin.Context) {
 		return
 	}
 
-	// Parse date range
-	endDate := time.Now()
+	// Parse date range, defaulting to the last week of operational days of the festival
+	endDate := h.service.Calendar(c.Request.Context(), festivalID).DateOf(time.Now())
 	startDate := endDate.AddDate(0, 0, -7)
 
 	if startStr := c.Query("start_date"); startStr != "" {
@@ -408,3 +725,36 @@ func (h *Handler) GetStandStats(c *gin.Context) {
 
 	response.OK(c, stats)
 }
+
+// GetStandCashierAnalytics returns per-cashier analytics for a stand
+// @Summary Get stand cashier analytics
+// @Description Get per-cashier analytics for a stand, flagging cashiers that deviate from the other cashiers of the stand
+// @Tags stats
+// @Produce json
+// @Param id path string true "Stand ID"
+// @Param timeframe query string false "Timeframe (TODAY, WEEK, MONTH, ALL)" default(TODAY)
+// @Success 200 {object} CashierAnalytics
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /stands/{id}/stats/cashiers [get]
+func (h *Handler) GetStandCashierAnalytics(c *gin.Context) {
+	standID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+		return
+	}
+
+	timeframe := ParseTimeframe(c.DefaultQuery("timeframe", "TODAY"))
+
+	analytics, err := h.service.GetStandCashierAnalytics(c.Request.Context(), standID, timeframe)
+	if err != nil {
+		if err == errors.ErrNotFound {
+			response.NotFound(c, "Stand not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, analytics)
+}
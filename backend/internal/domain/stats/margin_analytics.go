@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Margin is the revenue and cost of goods of a group of paid orders. Order items sold
+// before their product had a cost price are left out of the margin and reported as
+// uncosted revenue.
+type Margin struct {
+	StandID         *uuid.UUID `json:"standId,omitempty"`
+	StandName       string     `json:"standName,omitempty"`
+	Orders          int64      `json:"orders"`
+	Revenue         int64      `json:"revenue"`         // Paid order items, in cents
+	UncostedRevenue int64      `json:"uncostedRevenue"` // Items without a cost price
+	CostOfGoods     int64      `json:"costOfGoods"`
+	GrossMargin     int64      `json:"grossMargin"`   // Revenue of the costed items minus their cost
+	MarginPercent   float64    `json:"marginPercent"` // Gross margin over the revenue of the costed items
+}
+
+// OrderMargin is the gross margin of one paid order
+type OrderMargin struct {
+	OrderID         uuid.UUID `json:"orderId"`
+	StandID         uuid.UUID `json:"standId"`
+	StandName       string    `json:"standName"`
+	CreatedAt       time.Time `json:"createdAt"`
+	Revenue         int64     `json:"revenue"`
+	UncostedRevenue int64     `json:"uncostedRevenue"`
+	CostOfGoods     int64     `json:"costOfGoods"`
+	GrossMargin     int64     `json:"grossMargin"`
+	MarginPercent   float64   `json:"marginPercent"`
+}
+
+// MarginAnalytics is the gross margin of the paid orders of a festival, in total and by stand
+type MarginAnalytics struct {
+	FestivalID  uuid.UUID `json:"festivalId"`
+	Timeframe   string    `json:"timeframe"`
+	Totals      Margin    `json:"totals"`
+	ByStand     []Margin  `json:"byStand"` // Highest revenue first
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// ComputeMargins sums the margins of the stands into the festival totals and computes
+// the gross margin of each
+func ComputeMargins(festivalID uuid.UUID, timeframe Timeframe, stands []Margin, now time.Time) *MarginAnalytics {
+	analytics := &MarginAnalytics{
+		FestivalID:  festivalID,
+		Timeframe:   string(timeframe),
+		ByStand:     make([]Margin, len(stands)),
+		GeneratedAt: now,
+	}
+	for i, stand := range stands {
+		stand.computeMargin()
+		analytics.ByStand[i] = stand
+		analytics.Totals.Orders += stand.Orders
+		analytics.Totals.Revenue += stand.Revenue
+		analytics.Totals.UncostedRevenue += stand.UncostedRevenue
+		analytics.Totals.CostOfGoods += stand.CostOfGoods
+	}
+	analytics.Totals.computeMargin()
+	return analytics
+}
+
+func (m *Margin) computeMargin() {
+	m.GrossMargin, m.MarginPercent = grossMargin(m.Revenue, m.UncostedRevenue, m.CostOfGoods)
+}
+
+func (m *OrderMargin) computeMargin() {
+	m.GrossMargin, m.MarginPercent = grossMargin(m.Revenue, m.UncostedRevenue, m.CostOfGoods)
+}
+
+// grossMargin returns the margin on the costed revenue and its share of that revenue in percent
+func grossMargin(revenue, uncosted, costOfGoods int64) (int64, float64) {
+	costed := revenue - uncosted
+	margin := costed - costOfGoods
+	if costed <= 0 {
+		return margin, 0
+	}
+	return margin, roundTo(float64(margin)/float64(costed)*100, 1)
+}
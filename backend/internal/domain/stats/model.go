@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
 )
 
 // Timeframe represents the time period for stats aggregation
@@ -33,12 +34,13 @@ func ParseTimeframe(s string) Timeframe {
 	}
 }
 
-// GetStartTime returns the start time for a given timeframe
-func (t Timeframe) GetStartTime() time.Time {
-	now := time.Now()
+// StartTime returns the start time for a given timeframe; TODAY starts at the
+// beginning of the festival's operational day rather than at the server's midnight
+func (t Timeframe) StartTime(cal tz.Calendar) time.Time {
+	now := time.Now().In(cal.Location)
 	switch t {
 	case TimeframeToday:
-		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return cal.StartOfDay(now)
 	case TimeframeWeek:
 		return now.AddDate(0, 0, -7)
 	case TimeframeMonth:
@@ -46,7 +48,7 @@ func (t Timeframe) GetStartTime() time.Time {
 	case TimeframeAll:
 		return time.Time{} // Zero time means no filter
 	default:
-		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return cal.StartOfDay(now)
 	}
 }
 
@@ -66,6 +68,7 @@ type FestivalStats struct {
 	TotalStands         int       `json:"totalStands"`         // Number of stands
 	ActiveStands        int       `json:"activeStands"`        // Stands with transactions
 	Timeframe           Timeframe `json:"timeframe"`
+	Timezone            string    `json:"timezone"`            // Festival timezone of day boundaries
 	GeneratedAt         time.Time `json:"generatedAt"`
 }
 
@@ -89,6 +92,7 @@ type FestivalStatsResponse struct {
 	TotalStands         int       `json:"totalStands"`
 	ActiveStands        int       `json:"activeStands"`
 	Timeframe           string    `json:"timeframe"`
+	Timezone            string    `json:"timezone"`
 	GeneratedAt         string    `json:"generatedAt"`
 }
 
@@ -118,13 +122,14 @@ func (s *FestivalStats) ToResponse() FestivalStatsResponse {
 		TotalStands:         s.TotalStands,
 		ActiveStands:        s.ActiveStands,
 		Timeframe:           string(s.Timeframe),
+		Timezone:            s.Timezone,
 		GeneratedAt:         s.GeneratedAt.Format(time.RFC3339),
 	}
 }
 
-// DailyStats represents statistics for a single day
+// DailyStats represents statistics for a single day in the festival's timezone
 type DailyStats struct {
-	Date              time.Time `json:"date"`
+	Date              time.Time `json:"date"`              // Local midnight starting the day
 	Revenue           int64     `json:"revenue"`           // Revenue in cents
 	Transactions      int       `json:"transactions"`      // Number of transactions
 	NewWallets        int       `json:"newWallets"`        // New wallets created
@@ -177,6 +182,8 @@ type StandStats struct {
 	Transactions      int            `json:"transactions"`      // Number of transactions
 	AverageTransaction int64         `json:"averageTransaction"`// Average transaction amount
 	UniqueCustomers   int            `json:"uniqueCustomers"`   // Unique wallets
+	VoidedOrders      int            `json:"voidedOrders"`      // Orders voided as staff errors
+	VoidedAmount      int64          `json:"voidedAmount"`      // Total of voided orders in cents
 	TopProducts       []ProductStats `json:"topProducts"`       // Top selling products
 	Timeframe         Timeframe      `json:"timeframe"`
 }
@@ -191,6 +198,9 @@ type StandStatsResponse struct {
 	AverageTransaction  int64                 `json:"averageTransaction"`
 	AverageTransactionDisplay string          `json:"averageTransactionDisplay"`
 	UniqueCustomers     int                   `json:"uniqueCustomers"`
+	VoidedOrders        int                   `json:"voidedOrders"`
+	VoidedAmount        int64                 `json:"voidedAmount"`
+	VoidedDisplay       string                `json:"voidedAmountDisplay"`
 	TopProducts         []ProductStatsResponse `json:"topProducts"`
 	Timeframe           string                `json:"timeframe"`
 }
@@ -211,6 +221,9 @@ func (s *StandStats) ToResponse() StandStatsResponse {
 		AverageTransaction: s.AverageTransaction,
 		AverageTransactionDisplay: formatCurrency(s.AverageTransaction),
 		UniqueCustomers:    s.UniqueCustomers,
+		VoidedOrders:       s.VoidedOrders,
+		VoidedAmount:       s.VoidedAmount,
+		VoidedDisplay:      formatCurrency(s.VoidedAmount),
 		TopProducts:        topProducts,
 		Timeframe:          string(s.Timeframe),
 	}
@@ -255,6 +268,20 @@ func (p *ProductStats) ToResponse() ProductStatsResponse {
 	}
 }
 
+// CategoryRevenue represents sales attributed to a festival product category.
+// DirectRevenue only counts products assigned to the category itself while
+// TotalRevenue also includes its subcategories. A nil CategoryID groups
+// uncategorized products.
+type CategoryRevenue struct {
+	CategoryID     *uuid.UUID `json:"categoryId,omitempty"`
+	CategoryName   string     `json:"categoryName"`
+	ParentID       *uuid.UUID `json:"parentId,omitempty"`
+	QuantitySold   int        `json:"quantitySold"`
+	DirectRevenue  int64      `json:"directRevenue"`
+	TotalRevenue   int64      `json:"totalRevenue"`
+	RevenueDisplay string     `json:"revenueDisplay"`
+}
+
 // StaffPerformance represents statistics for a staff member
 type StaffPerformance struct {
 	StaffID           uuid.UUID `json:"staffId"`
@@ -336,6 +363,7 @@ func (rt *RecentTransaction) ToResponse() RecentTransactionResponse {
 
 // RevenueChartData represents revenue data for charting
 type RevenueChartData struct {
+	Timezone string   `json:"timezone"` // Festival timezone of the date labels
 	Labels   []string `json:"labels"`   // Date labels
 	Revenue  []int64  `json:"revenue"`  // Revenue values
 	TopUps   []int64  `json:"topUps"`   // Top-up values
@@ -7,11 +7,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
 	"gorm.io/gorm"
 )
 
 // Repository defines the interface for stats data access
 type Repository interface {
+	GetFestivalCalendar(ctx context.Context, festivalID uuid.UUID) (tz.Calendar, error)
 	GetFestivalStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*FestivalStats, error)
 	GetDailyStats(ctx context.Context, festivalID uuid.UUID, startDate, endDate time.Time) ([]DailyStats, error)
 	GetStandStats(ctx context.Context, standID uuid.UUID, timeframe Timeframe) (*StandStats, error)
@@ -19,6 +21,26 @@ type Repository interface {
 	GetRecentTransactions(ctx context.Context, festivalID uuid.UUID, limit int) ([]RecentTransaction, error)
 	GetTopStands(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]StandStats, error)
 	GetStaffPerformance(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]StaffPerformance, error)
+	GetRevenueByCategory(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]CategoryRevenue, error)
+	GetHourlyStaffing(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]HourlyStaffing, error)
+	GetFestivalsWithOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	ReplaceStaffingRecommendations(ctx context.Context, festivalID uuid.UUID, recommendations []StaffingRecommendation) error
+	GetStaffingRecommendations(ctx context.Context, festivalID uuid.UUID) ([]StaffingRecommendation, error)
+	GetHourlyWeatherRevenue(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]HourlyWeatherRevenue, error)
+	GetCashierActivity(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, timeframe Timeframe) ([]CashierActivity, error)
+	GetDeliveryRecords(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]DeliveryRecord, error)
+	GetStandMargins(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]Margin, error)
+	ListOrderMargins(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, timeframe Timeframe, offset, limit int) ([]OrderMargin, int64, error)
+	GetFestivalsWithSales(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	GetDashboardWatermark(ctx context.Context, festivalID uuid.UUID) (*time.Time, error)
+	MaterializeDashboardAggregates(ctx context.Context, festivalID uuid.UUID, until time.Time, lag time.Duration, rebuild bool) error
+	GetAggregatedFestivalStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*FestivalStats, error)
+	GetAggregatedDailyRevenue(ctx context.Context, festivalID uuid.UUID, startDate, endDate time.Time) ([]DailyStats, error)
+	GetAggregatedTopProducts(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]ProductStats, error)
+	GetAggregatedTopStands(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]StandStats, error)
+	GetEdition(ctx context.Context, festivalID uuid.UUID) (*Edition, error)
+	GetEditionHourlySales(ctx context.Context, edition *Edition) ([]EditionHourlySales, error)
+	GetEditionHourlyEntries(ctx context.Context, edition *Edition) ([]EditionHourlyEntries, error)
 }
 
 type repository struct {
@@ -30,65 +52,68 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-// GetFestivalStats retrieves aggregated statistics for a festival
-func (r *repository) GetFestivalStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*FestivalStats, error) {
-	stats := &FestivalStats{
-		FestivalID:  festivalID,
-		Timeframe:   timeframe,
-		GeneratedAt: time.Now(),
+// GetFestivalCalendar retrieves the timezone and operational day start of a festival,
+// falling back to the defaults for unknown festivals or invalid settings
+func (r *repository) GetFestivalCalendar(ctx context.Context, festivalID uuid.UUID) (tz.Calendar, error) {
+	var festivals []struct {
+		Timezone    string
+		DayStartsAt string
 	}
-
-	startTime := timeframe.GetStartTime()
-	timeFilter := ""
-	args := []interface{}{festivalID}
-
-	if !startTime.IsZero() {
-		timeFilter = " AND created_at >= ?"
-		args = append(args, startTime)
+	if err := r.db.WithContext(ctx).Raw(
+		"SELECT timezone, day_starts_at FROM public.festivals WHERE id = ?",
+		festivalID,
+	).Scan(&festivals).Error; err != nil {
+		return tz.Calendar{}, fmt.Errorf("failed to get festival calendar: %w", err)
+	}
+	if len(festivals) == 0 {
+		return tz.NewCalendar("", ""), nil
 	}
+	return tz.NewCalendar(festivals[0].Timezone, festivals[0].DayStartsAt), nil
+}
 
-	// Get ticket stats
-	ticketQuery := `
-		SELECT
-			COALESCE(COUNT(*), 0) as tickets_sold,
-			COALESCE(SUM(CASE WHEN status = 'USED' THEN 1 ELSE 0 END), 0) as tickets_checked_in
-		FROM public.tickets
-		WHERE festival_id = ?` + timeFilter
+// festivalCalendar is GetFestivalCalendar for queries that fall back to the
+// default calendar rather than fail
+func (r *repository) festivalCalendar(ctx context.Context, festivalID uuid.UUID) tz.Calendar {
+	cal, err := r.GetFestivalCalendar(ctx, festivalID)
+	if err != nil {
+		return tz.NewCalendar("", "")
+	}
+	return cal
+}
 
-	var ticketStats struct {
-		TicketsSold      int
-		TicketsCheckedIn int
+// standCalendar returns the calendar of the festival a stand belongs to
+func (r *repository) standCalendar(ctx context.Context, standID uuid.UUID) tz.Calendar {
+	var festivals []struct {
+		Timezone    string
+		DayStartsAt string
 	}
-	if err := r.db.WithContext(ctx).Raw(ticketQuery, args...).Scan(&ticketStats).Error; err != nil {
-		return nil, fmt.Errorf("failed to get ticket stats: %w", err)
+	r.db.WithContext(ctx).Raw(`
+		SELECT f.timezone, f.day_starts_at
+		FROM public.stands s
+		INNER JOIN public.festivals f ON f.id = s.festival_id
+		WHERE s.id = ?`,
+		standID,
+	).Scan(&festivals)
+	if len(festivals) == 0 {
+		return tz.NewCalendar("", "")
 	}
-	stats.TicketsSold = ticketStats.TicketsSold
-	stats.TicketsCheckedIn = ticketStats.TicketsCheckedIn
+	return tz.NewCalendar(festivals[0].Timezone, festivals[0].DayStartsAt)
+}
 
-	// Get wallet stats
-	walletArgs := []interface{}{festivalID}
-	walletTimeFilter := ""
-	if !startTime.IsZero() {
-		walletTimeFilter = " AND created_at >= ?"
-		walletArgs = append(walletArgs, startTime)
+// GetFestivalStats retrieves aggregated statistics for a festival
+func (r *repository) GetFestivalStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*FestivalStats, error) {
+	cal := r.festivalCalendar(ctx, festivalID)
+	stats := &FestivalStats{
+		FestivalID:  festivalID,
+		Timeframe:   timeframe,
+		Timezone:    cal.Location.String(),
+		GeneratedAt: time.Now().In(cal.Location),
 	}
 
-	walletQuery := `
-		SELECT
-			COALESCE(COUNT(*), 0) as total_wallets,
-			COALESCE(SUM(CASE WHEN balance > 0 THEN 1 ELSE 0 END), 0) as active_wallets
-		FROM public.wallets
-		WHERE festival_id = ?` + walletTimeFilter
-
-	var walletStats struct {
-		TotalWallets  int
-		ActiveWallets int
-	}
-	if err := r.db.WithContext(ctx).Raw(walletQuery, walletArgs...).Scan(&walletStats).Error; err != nil {
-		return nil, fmt.Errorf("failed to get wallet stats: %w", err)
+	startTime := timeframe.StartTime(cal)
+	if err := r.fillTicketAndWalletStats(ctx, stats, festivalID, startTime); err != nil {
+		return nil, err
 	}
-	stats.TotalWallets = walletStats.TotalWallets
-	stats.ActiveWallets = walletStats.ActiveWallets
 
 	// Get transaction stats - join with wallets to filter by festival
 	txArgs := []interface{}{festivalID}
@@ -159,8 +184,70 @@ func (r *repository) GetFestivalStats(ctx context.Context, festivalID uuid.UUID,
 	return stats, nil
 }
 
-// GetDailyStats retrieves daily statistics for a festival within a date range
+// fillTicketAndWalletStats sets the ticket and wallet counts of festival stats
+func (r *repository) fillTicketAndWalletStats(ctx context.Context, stats *FestivalStats, festivalID uuid.UUID, startTime time.Time) error {
+	timeFilter := ""
+	args := []interface{}{festivalID}
+
+	if !startTime.IsZero() {
+		timeFilter = " AND created_at >= ?"
+		args = append(args, startTime)
+	}
+
+	// Get ticket stats
+	ticketQuery := `
+		SELECT
+			COALESCE(COUNT(*), 0) as tickets_sold,
+			COALESCE(SUM(CASE WHEN status = 'USED' THEN 1 ELSE 0 END), 0) as tickets_checked_in
+		FROM public.tickets
+		WHERE festival_id = ?` + timeFilter
+
+	var ticketStats struct {
+		TicketsSold      int
+		TicketsCheckedIn int
+	}
+	if err := r.db.WithContext(ctx).Raw(ticketQuery, args...).Scan(&ticketStats).Error; err != nil {
+		return fmt.Errorf("failed to get ticket stats: %w", err)
+	}
+	stats.TicketsSold = ticketStats.TicketsSold
+	stats.TicketsCheckedIn = ticketStats.TicketsCheckedIn
+
+	// Get wallet stats
+	walletArgs := []interface{}{festivalID}
+	walletTimeFilter := ""
+	if !startTime.IsZero() {
+		walletTimeFilter = " AND created_at >= ?"
+		walletArgs = append(walletArgs, startTime)
+	}
+
+	walletQuery := `
+		SELECT
+			COALESCE(COUNT(*), 0) as total_wallets,
+			COALESCE(SUM(CASE WHEN balance > 0 THEN 1 ELSE 0 END), 0) as active_wallets
+		FROM public.wallets
+		WHERE festival_id = ?` + walletTimeFilter
+
+	var walletStats struct {
+		TotalWallets  int
+		ActiveWallets int
+	}
+	if err := r.db.WithContext(ctx).Raw(walletQuery, walletArgs...).Scan(&walletStats).Error; err != nil {
+		return fmt.Errorf("failed to get wallet stats: %w", err)
+	}
+	stats.TotalWallets = walletStats.TotalWallets
+	stats.ActiveWallets = walletStats.ActiveWallets
+
+	return nil
+}
+
+// GetDailyStats retrieves daily statistics for a date range; days are the operational
+// days of the festival, from its day start to the next one in its timezone
 func (r *repository) GetDailyStats(ctx context.Context, festivalID uuid.UUID, startDate, endDate time.Time) ([]DailyStats, error) {
+	cal := r.festivalCalendar(ctx, festivalID)
+	timezone, offset := cal.Location.String(), cal.Offset()
+	rangeStart, _ := cal.Bounds(startDate)
+	_, rangeEnd := cal.Bounds(endDate)
+
 	query := `
 		WITH date_series AS (
 			SELECT generate_series(
@@ -171,7 +258,7 @@ func (r *repository) GetDailyStats(ctx context.Context, festivalID uuid.UUID, st
 		),
 		daily_transactions AS (
 			SELECT
-				DATE(t.created_at) as date,
+				((t.created_at AT TIME ZONE ?) - make_interval(mins => ?))::date as date,
 				SUM(CASE WHEN t.type IN ('TOP_UP', 'CASH_IN') THEN ABS(t.amount) ELSE 0 END) as top_ups,
 				SUM(CASE WHEN t.type = 'PURCHASE' THEN ABS(t.amount) ELSE 0 END) as purchases,
 				COUNT(*) as transactions,
@@ -181,39 +268,39 @@ func (r *repository) GetDailyStats(ctx context.Context, festivalID uuid.UUID, st
 			WHERE w.festival_id = ?
 				AND t.status = 'COMPLETED'
 				AND t.created_at >= ?
-				AND t.created_at < ? + INTERVAL '1 day'
-			GROUP BY DATE(t.created_at)
+				AND t.created_at < ?
+			GROUP BY 1
 		),
 		daily_wallets AS (
 			SELECT
-				DATE(created_at) as date,
+				((created_at AT TIME ZONE ?) - make_interval(mins => ?))::date as date,
 				COUNT(*) as new_wallets
 			FROM public.wallets
 			WHERE festival_id = ?
 				AND created_at >= ?
-				AND created_at < ? + INTERVAL '1 day'
-			GROUP BY DATE(created_at)
+				AND created_at < ?
+			GROUP BY 1
 		),
 		daily_tickets AS (
 			SELECT
-				DATE(created_at) as date,
+				((created_at AT TIME ZONE ?) - make_interval(mins => ?))::date as date,
 				COUNT(*) as tickets_sold
 			FROM public.tickets
 			WHERE festival_id = ?
 				AND created_at >= ?
-				AND created_at < ? + INTERVAL '1 day'
-			GROUP BY DATE(created_at)
+				AND created_at < ?
+			GROUP BY 1
 		),
 		daily_checkins AS (
 			SELECT
-				DATE(checked_in_at) as date,
+				((checked_in_at AT TIME ZONE ?) - make_interval(mins => ?))::date as date,
 				COUNT(*) as tickets_checked_in
 			FROM public.tickets
 			WHERE festival_id = ?
 				AND checked_in_at IS NOT NULL
 				AND checked_in_at >= ?
-				AND checked_in_at < ? + INTERVAL '1 day'
-			GROUP BY DATE(checked_in_at)
+				AND checked_in_at < ?
+			GROUP BY 1
 		)
 		SELECT
 			ds.date,
@@ -233,11 +320,11 @@ func (r *repository) GetDailyStats(ctx context.Context, festivalID uuid.UUID, st
 		ORDER BY ds.date ASC`
 
 	args := []interface{}{
-		startDate, endDate,
-		festivalID, startDate, endDate,
-		festivalID, startDate, endDate,
-		festivalID, startDate, endDate,
-		festivalID, startDate, endDate,
+		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"),
+		timezone, offset, festivalID, rangeStart, rangeEnd,
+		timezone, offset, festivalID, rangeStart, rangeEnd,
+		timezone, offset, festivalID, rangeStart, rangeEnd,
+		timezone, offset, festivalID, rangeStart, rangeEnd,
 	}
 
 	var results []struct {
@@ -259,7 +346,7 @@ func (r *repository) GetDailyStats(ctx context.Context, festivalID uuid.UUID, st
 	dailyStats := make([]DailyStats, len(results))
 	for i, r := range results {
 		dailyStats[i] = DailyStats{
-			Date:             r.Date,
+			Date:             tz.Date(r.Date, cal.Location),
 			Revenue:          r.Revenue,
 			Transactions:     r.Transactions,
 			NewWallets:       r.NewWallets,
@@ -276,7 +363,7 @@ func (r *repository) GetDailyStats(ctx context.Context, festivalID uuid.UUID, st
 
 // GetStandStats retrieves statistics for a specific stand
 func (r *repository) GetStandStats(ctx context.Context, standID uuid.UUID, timeframe Timeframe) (*StandStats, error) {
-	startTime := timeframe.GetStartTime()
+	startTime := timeframe.StartTime(r.standCalendar(ctx, standID))
 	timeFilter := ""
 	args := []interface{}{standID}
 
@@ -319,6 +406,28 @@ func (r *repository) GetStandStats(ctx context.Context, standID uuid.UUID, timef
 		return nil, fmt.Errorf("failed to get stand stats: %w", err)
 	}
 
+	// Get voided orders for this stand
+	voidQuery := `
+		SELECT
+			COUNT(*) as voided_orders,
+			COALESCE(SUM(o.total_amount), 0) as voided_amount
+		FROM public.orders o
+		WHERE o.stand_id = ?
+			AND o.status = 'VOIDED'`
+	voidArgs := []interface{}{standID}
+	if !startTime.IsZero() {
+		voidQuery += " AND o.created_at >= ?"
+		voidArgs = append(voidArgs, startTime)
+	}
+
+	var voids struct {
+		VoidedOrders int
+		VoidedAmount int64
+	}
+	if err := r.db.WithContext(ctx).Raw(voidQuery, voidArgs...).Scan(&voids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stand voids: %w", err)
+	}
+
 	// Get top products for this stand
 	topProducts, err := r.getStandTopProducts(ctx, standID, 5, timeframe)
 	if err != nil {
@@ -332,6 +441,8 @@ func (r *repository) GetStandStats(ctx context.Context, standID uuid.UUID, timef
 		Transactions:       result.Transactions,
 		AverageTransaction: int64(result.AverageTransaction),
 		UniqueCustomers:    result.UniqueCustomers,
+		VoidedOrders:       voids.VoidedOrders,
+		VoidedAmount:       voids.VoidedAmount,
 		TopProducts:        topProducts,
 		Timeframe:          timeframe,
 	}
@@ -341,7 +452,7 @@ func (r *repository) GetStandStats(ctx context.Context, standID uuid.UUID, timef
 
 // getStandTopProducts retrieves top products for a specific stand
 func (r *repository) getStandTopProducts(ctx context.Context, standID uuid.UUID, limit int, timeframe Timeframe) ([]ProductStats, error) {
-	startTime := timeframe.GetStartTime()
+	startTime := timeframe.StartTime(r.standCalendar(ctx, standID))
 	timeFilter := ""
 	args := []interface{}{standID}
 
@@ -431,7 +542,7 @@ func (r *repository) getStandTopProducts(ctx context.Context, standID uuid.UUID,
 
 // GetTopProducts retrieves top selling products across all stands for a festival
 func (r *repository) GetTopProducts(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]ProductStats, error) {
-	startTime := timeframe.GetStartTime()
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
 	timeFilter := ""
 	args := []interface{}{festivalID}
 
@@ -557,6 +668,8 @@ func (r *repository) GetRecentTransactions(ctx context.Context, festivalID uuid.
 		return nil, fmt.Errorf("failed to get recent transactions: %w", err)
 	}
 
+	loc := r.festivalCalendar(ctx, festivalID).Location
+
 	transactions := make([]RecentTransaction, len(results))
 	for i, r := range results {
 		tx := RecentTransaction{
@@ -565,7 +678,7 @@ func (r *repository) GetRecentTransactions(ctx context.Context, festivalID uuid.
 			Amount:    r.Amount,
 			StandName: r.StandName,
 			StaffName: r.StaffName,
-			CreatedAt: r.CreatedAt,
+			CreatedAt: r.CreatedAt.In(loc),
 		}
 
 		if r.StandID.Valid {
@@ -585,7 +698,7 @@ func (r *repository) GetRecentTransactions(ctx context.Context, festivalID uuid.
 
 // GetTopStands retrieves top performing stands for a festival
 func (r *repository) GetTopStands(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]StandStats, error) {
-	startTime := timeframe.GetStartTime()
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
 	timeFilter := ""
 	args := []interface{}{festivalID}
 
@@ -648,7 +761,7 @@ func (r *repository) GetTopStands(ctx context.Context, festivalID uuid.UUID, lim
 
 // GetStaffPerformance retrieves performance statistics for all staff at a festival
 func (r *repository) GetStaffPerformance(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]StaffPerformance, error) {
-	startTime := timeframe.GetStartTime()
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
 	timeFilter := ""
 	args := []interface{}{festivalID}
 
@@ -705,3 +818,852 @@ func (r *repository) GetStaffPerformance(ctx context.Context, festivalID uuid.UU
 
 	return performance, nil
 }
+
+// GetRevenueByCategory retrieves the product categories of a festival with the sales of
+// the products directly in each. Categories without direct sales are returned too, for
+// the revenue of their subcategories to roll up into; sales of uncategorized products
+// come in a row without category ID. Only direct revenue is filled in; subcategory
+// roll-ups are computed by the service.
+func (r *repository) GetRevenueByCategory(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]CategoryRevenue, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+	timeFilter := ""
+	args := []interface{}{festivalID}
+
+	if !startTime.IsZero() {
+		timeFilter = " AND ps.created_at >= ?"
+		args = append(args, startTime)
+	}
+	args = append(args, festivalID, festivalID)
+
+	query := `
+		WITH sales AS (
+			SELECT
+				p.category_id,
+				SUM(ps.quantity) as quantity_sold,
+				SUM(ps.amount) as direct_revenue
+			FROM public.product_sales ps
+			INNER JOIN public.products p ON ps.product_id = p.id
+			INNER JOIN public.stands s ON p.stand_id = s.id
+			WHERE s.festival_id = ?` + timeFilter + `
+			GROUP BY p.category_id
+		)
+		SELECT
+			c.id as category_id,
+			c.name as category_name,
+			c.parent_id,
+			COALESCE(sales.quantity_sold, 0) as quantity_sold,
+			COALESCE(sales.direct_revenue, 0) as direct_revenue
+		FROM public.categories c
+		LEFT JOIN sales ON sales.category_id = c.id
+		WHERE c.festival_id = ? AND c.type = 'PRODUCT'
+		UNION ALL
+		SELECT NULL, 'Uncategorized', NULL, SUM(sales.quantity_sold), SUM(sales.direct_revenue)
+		FROM sales
+		WHERE sales.category_id IS NULL OR NOT EXISTS (
+			SELECT 1 FROM public.categories c
+			WHERE c.id = sales.category_id AND c.festival_id = ? AND c.type = 'PRODUCT'
+		)
+		HAVING COUNT(*) > 0`
+
+	var results []struct {
+		CategoryID    *uuid.UUID
+		CategoryName  string
+		ParentID      *uuid.UUID
+		QuantitySold  int
+		DirectRevenue int64
+	}
+
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revenue by category: %w", err)
+	}
+
+	categories := make([]CategoryRevenue, len(results))
+	for i, res := range results {
+		categories[i] = CategoryRevenue{
+			CategoryID:    res.CategoryID,
+			CategoryName:  res.CategoryName,
+			ParentID:      res.ParentID,
+			QuantitySold:  res.QuantitySold,
+			DirectRevenue: res.DirectRevenue,
+		}
+	}
+
+	return categories, nil
+}
+
+// GetHourlyStaffing retrieves the average paid orders and distinct staff per stand and
+// hour of day (in the festival timezone) since the given time
+func (r *repository) GetHourlyStaffing(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]HourlyStaffing, error) {
+	query := `
+		WITH slots AS (
+			SELECT
+				o.stand_id,
+				date_trunc('hour', o.created_at AT TIME ZONE f.timezone) as slot,
+				COUNT(*) as orders,
+				COUNT(DISTINCT o.staff_id) as staff
+			FROM public.orders o
+			INNER JOIN public.festivals f ON o.festival_id = f.id
+			WHERE o.festival_id = ?
+				AND o.status = 'PAID'
+				AND o.created_at >= ?
+			GROUP BY o.stand_id, slot
+		),
+		roster AS (
+			SELECT stand_id, COUNT(*) as roster_staff
+			FROM public.stand_staff
+			WHERE role IN ('CASHIER', 'MANAGER')
+			GROUP BY stand_id
+		)
+		SELECT
+			sl.stand_id,
+			s.name as stand_name,
+			EXTRACT(HOUR FROM sl.slot)::int as hour,
+			COUNT(*) as days,
+			AVG(sl.orders) as avg_orders,
+			AVG(sl.staff) as avg_staff,
+			COALESCE(MAX(ro.roster_staff), 0) as roster_staff
+		FROM slots sl
+		INNER JOIN public.stands s ON sl.stand_id = s.id
+		LEFT JOIN roster ro ON ro.stand_id = sl.stand_id
+		GROUP BY sl.stand_id, s.name, hour
+		ORDER BY s.name, hour`
+
+	var hours []HourlyStaffing
+	if err := r.db.WithContext(ctx).Raw(query, festivalID, since).Scan(&hours).Error; err != nil {
+		return nil, fmt.Errorf("failed to get hourly staffing: %w", err)
+	}
+
+	return hours, nil
+}
+
+// GetFestivalsWithOrders retrieves the festivals that received paid orders since the given time
+func (r *repository) GetFestivalsWithOrders(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("public.orders").
+		Distinct("festival_id").
+		Where("status = ? AND created_at >= ?", "PAID", since).
+		Pluck("festival_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festivals with orders: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ReplaceStaffingRecommendations replaces the stored staffing recommendations of a festival
+func (r *repository) ReplaceStaffingRecommendations(ctx context.Context, festivalID uuid.UUID, recommendations []StaffingRecommendation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("festival_id = ?", festivalID).Delete(&StaffingRecommendation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete staffing recommendations: %w", err)
+		}
+		if len(recommendations) == 0 {
+			return nil
+		}
+		if err := tx.Create(&recommendations).Error; err != nil {
+			return fmt.Errorf("failed to create staffing recommendations: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetStaffingRecommendations retrieves the stored staffing recommendations of a festival
+func (r *repository) GetStaffingRecommendations(ctx context.Context, festivalID uuid.UUID) ([]StaffingRecommendation, error) {
+	var recommendations []StaffingRecommendation
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ?", festivalID).
+		Order("stand_name ASC, start_hour ASC").
+		Find(&recommendations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staffing recommendations: %w", err)
+	}
+
+	return recommendations, nil
+}
+
+// GetHourlyWeatherRevenue retrieves the purchase revenue of every hour with sales,
+// joined with the weather observed at the festival during that hour
+func (r *repository) GetHourlyWeatherRevenue(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]HourlyWeatherRevenue, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+	timeFilter := ""
+	args := []interface{}{festivalID}
+
+	if !startTime.IsZero() {
+		timeFilter = " AND t.created_at >= ?"
+		args = append(args, startTime)
+	}
+
+	query := `
+		WITH hourly AS (
+			SELECT
+				date_trunc('hour', t.created_at) as hour,
+				SUM(ABS(t.amount)) as revenue,
+				COUNT(*) as transactions
+			FROM public.transactions t
+			INNER JOIN public.wallets w ON t.wallet_id = w.id
+			WHERE w.festival_id = ?
+				AND t.type = 'PURCHASE'
+				AND t.status = 'COMPLETED'` + timeFilter + `
+			GROUP BY date_trunc('hour', t.created_at)
+		)
+		SELECT
+			h.hour,
+			EXTRACT(HOUR FROM h.hour AT TIME ZONE f.timezone)::int as local_hour,
+			h.revenue,
+			h.transactions,
+			wo.condition,
+			wo.temperature_c,
+			wo.precipitation_mm
+		FROM hourly h
+		INNER JOIN public.festivals f ON f.id = ?
+		LEFT JOIN public.weather_observations wo ON wo.festival_id = f.id AND wo.observed_at = h.hour
+		ORDER BY h.hour ASC`
+	args = append(args, festivalID)
+
+	var hours []HourlyWeatherRevenue
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&hours).Error; err != nil {
+		return nil, fmt.Errorf("failed to get hourly weather revenue: %w", err)
+	}
+
+	return hours, nil
+}
+
+// GetCashierActivity retrieves the sales, refunds, top-ups and voided orders processed by
+// each staff member of a festival, optionally limited to one stand
+func (r *repository) GetCashierActivity(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, timeframe Timeframe) ([]CashierActivity, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+	txFilter := ""
+	orderFilter := ""
+	txArgs := []interface{}{festivalID}
+	orderArgs := []interface{}{festivalID}
+
+	if standID != nil {
+		txFilter += " AND t.stand_id = ?"
+		txArgs = append(txArgs, *standID)
+		orderFilter += " AND o.stand_id = ?"
+		orderArgs = append(orderArgs, *standID)
+	}
+	if !startTime.IsZero() {
+		txFilter += " AND t.created_at >= ?"
+		txArgs = append(txArgs, startTime)
+		orderFilter += " AND o.created_at >= ?"
+		orderArgs = append(orderArgs, startTime)
+	}
+
+	query := `
+		WITH tx AS (
+			SELECT
+				t.staff_id,
+				COUNT(*) FILTER (WHERE t.type = 'PURCHASE') as purchases,
+				COALESCE(SUM(ABS(t.amount)) FILTER (WHERE t.type = 'PURCHASE'), 0) as purchase_amount,
+				COUNT(*) FILTER (WHERE t.type = 'REFUND') as refunds,
+				COALESCE(SUM(ABS(t.amount)) FILTER (WHERE t.type = 'REFUND'), 0) as refund_amount,
+				COUNT(*) FILTER (WHERE t.type IN ('TOP_UP', 'CASH_IN')) as top_ups,
+				COALESCE(SUM(ABS(t.amount)) FILTER (WHERE t.type IN ('TOP_UP', 'CASH_IN')), 0) as top_up_amount,
+				COUNT(DISTINCT date_trunc('hour', t.created_at)) as active_hours
+			FROM public.transactions t
+			INNER JOIN public.wallets w ON t.wallet_id = w.id
+			WHERE w.festival_id = ?
+				AND t.status = 'COMPLETED'
+				AND t.staff_id IS NOT NULL` + txFilter + `
+			GROUP BY t.staff_id
+		),
+		voids AS (
+			SELECT o.staff_id, COUNT(*) as voids
+			FROM public.orders o
+			WHERE o.festival_id = ?
+				AND o.status IN ('CANCELLED', 'VOIDED')
+				AND o.staff_id IS NOT NULL` + orderFilter + `
+			GROUP BY o.staff_id
+		)
+		SELECT
+			COALESCE(tx.staff_id, v.staff_id) as staff_id,
+			COALESCE(u.name, 'Unknown') as staff_name,
+			COALESCE(tx.purchases, 0) as purchases,
+			COALESCE(tx.purchase_amount, 0) as purchase_amount,
+			COALESCE(tx.refunds, 0) as refunds,
+			COALESCE(tx.refund_amount, 0) as refund_amount,
+			COALESCE(tx.top_ups, 0) as top_ups,
+			COALESCE(tx.top_up_amount, 0) as top_up_amount,
+			COALESCE(v.voids, 0) as voids,
+			COALESCE(tx.active_hours, 0) as active_hours
+		FROM tx
+		FULL OUTER JOIN voids v ON v.staff_id = tx.staff_id
+		LEFT JOIN public.users u ON u.id = COALESCE(tx.staff_id, v.staff_id)
+		ORDER BY purchase_amount DESC`
+
+	var activity []CashierActivity
+	if err := r.db.WithContext(ctx).Raw(query, append(txArgs, orderArgs...)...).Scan(&activity).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cashier activity: %w", err)
+	}
+
+	return activity, nil
+}
+
+// GetFestivalsWithSales retrieves the festivals with completed transactions or paid orders since the given time
+func (r *repository) GetFestivalsWithSales(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT w.festival_id
+		FROM public.transactions t
+		INNER JOIN public.wallets w ON t.wallet_id = w.id
+		WHERE t.status = 'COMPLETED' AND t.created_at >= ?
+		UNION
+		SELECT festival_id
+		FROM public.orders
+		WHERE status = 'PAID' AND created_at >= ?`
+
+	var ids []uuid.UUID
+	if err := r.db.WithContext(ctx).Raw(query, since, since).Scan(&ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get festivals with sales: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetDashboardWatermark retrieves the time up to which the dashboard aggregates of a
+// festival are materialized, or nil if they never were
+func (r *repository) GetDashboardWatermark(ctx context.Context, festivalID uuid.UUID) (*time.Time, error) {
+	var watermarks []time.Time
+	if err := r.db.WithContext(ctx).Raw(
+		"SELECT materialized_until FROM public.dashboard_aggregate_watermarks WHERE festival_id = ?",
+		festivalID,
+	).Scan(&watermarks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dashboard watermark: %w", err)
+	}
+	if len(watermarks) == 0 {
+		return nil, nil
+	}
+	return &watermarks[0], nil
+}
+
+// MaterializeDashboardAggregates recomputes the dashboard aggregates of a festival from
+// the last watermark minus lag up to until, so transactions committed late are still
+// counted. Buckets in that range are replaced rather than incremented, which keeps the
+// refresh idempotent. rebuild recomputes every bucket of the festival.
+func (r *repository) MaterializeDashboardAggregates(ctx context.Context, festivalID uuid.UUID, until time.Time, lag time.Duration, rebuild bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize refreshes of the same festival
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "dashboard:"+festivalID.String()).Error; err != nil {
+			return fmt.Errorf("failed to lock dashboard aggregates: %w", err)
+		}
+
+		var watermarks []time.Time
+		if err := tx.Raw(
+			"SELECT materialized_until FROM public.dashboard_aggregate_watermarks WHERE festival_id = ?",
+			festivalID,
+		).Scan(&watermarks).Error; err != nil {
+			return fmt.Errorf("failed to get dashboard watermark: %w", err)
+		}
+
+		// Without a watermark, every bucket of the festival is (re)built
+		var minuteFrom, hourFrom time.Time
+		if len(watermarks) > 0 && !rebuild {
+			from := watermarks[0].Add(-lag).UTC()
+			minuteFrom = from.Truncate(time.Minute)
+			hourFrom = from.Truncate(time.Hour)
+		}
+
+		if err := tx.Exec(
+			"DELETE FROM public.dashboard_minute_aggregates WHERE festival_id = ? AND bucket >= ?",
+			festivalID, minuteFrom,
+		).Error; err != nil {
+			return fmt.Errorf("failed to clear minute aggregates: %w", err)
+		}
+
+		transactionQuery := `
+			INSERT INTO public.dashboard_minute_aggregates
+				(festival_id, stand_id, bucket, top_ups, purchases, refunds, volume, transactions, purchase_count, updated_at)
+			SELECT
+				w.festival_id,
+				COALESCE(t.stand_id, '00000000-0000-0000-0000-000000000000'::uuid),
+				date_trunc('minute', t.created_at),
+				SUM(CASE WHEN t.type IN ('TOP_UP', 'CASH_IN') THEN ABS(t.amount) ELSE 0 END),
+				SUM(CASE WHEN t.type = 'PURCHASE' THEN ABS(t.amount) ELSE 0 END),
+				SUM(CASE WHEN t.type = 'REFUND' THEN ABS(t.amount) ELSE 0 END),
+				SUM(ABS(t.amount)),
+				COUNT(*),
+				SUM(CASE WHEN t.type = 'PURCHASE' THEN 1 ELSE 0 END),
+				NOW()
+			FROM public.transactions t
+			INNER JOIN public.wallets w ON t.wallet_id = w.id
+			WHERE w.festival_id = ?
+				AND t.status = 'COMPLETED'
+				AND t.created_at >= ?
+				AND t.created_at < ?
+			GROUP BY 1, 2, 3`
+
+		if err := tx.Exec(transactionQuery, festivalID, minuteFrom, until).Error; err != nil {
+			return fmt.Errorf("failed to materialize transaction aggregates: %w", err)
+		}
+
+		orderQuery := `
+			INSERT INTO public.dashboard_minute_aggregates
+				(festival_id, stand_id, bucket, orders, order_revenue, updated_at)
+			SELECT
+				o.festival_id,
+				o.stand_id,
+				date_trunc('minute', o.created_at),
+				COUNT(*),
+				SUM(o.total_amount),
+				NOW()
+			FROM public.orders o
+			WHERE o.festival_id = ?
+				AND o.status = 'PAID'
+				AND o.created_at >= ?
+				AND o.created_at < ?
+			GROUP BY 1, 2, 3
+			ON CONFLICT (festival_id, bucket, stand_id) DO UPDATE SET
+				orders = EXCLUDED.orders,
+				order_revenue = EXCLUDED.order_revenue,
+				updated_at = EXCLUDED.updated_at`
+
+		if err := tx.Exec(orderQuery, festivalID, minuteFrom, until).Error; err != nil {
+			return fmt.Errorf("failed to materialize order aggregates: %w", err)
+		}
+
+		if err := tx.Exec(
+			"DELETE FROM public.dashboard_product_aggregates WHERE festival_id = ? AND bucket >= ?",
+			festivalID, hourFrom,
+		).Error; err != nil {
+			return fmt.Errorf("failed to clear product aggregates: %w", err)
+		}
+
+		productQuery := `
+			INSERT INTO public.dashboard_product_aggregates
+				(festival_id, stand_id, product_id, bucket, quantity, revenue, updated_at)
+			SELECT
+				o.festival_id,
+				o.stand_id,
+				(item->>'productId')::uuid,
+				date_trunc('hour', o.created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+				SUM((item->>'quantity')::int),
+				SUM((item->>'totalPrice')::bigint),
+				NOW()
+			FROM public.orders o
+			CROSS JOIN LATERAL jsonb_array_elements(o.items) AS item
+			WHERE o.festival_id = ?
+				AND o.status = 'PAID'
+				AND o.created_at >= ?
+				AND o.created_at < ?
+			GROUP BY 1, 2, 3, 4`
+
+		if err := tx.Exec(productQuery, festivalID, hourFrom, until).Error; err != nil {
+			return fmt.Errorf("failed to materialize product aggregates: %w", err)
+		}
+
+		watermarkQuery := `
+			INSERT INTO public.dashboard_aggregate_watermarks (festival_id, materialized_until, updated_at)
+			VALUES (?, ?, NOW())
+			ON CONFLICT (festival_id) DO UPDATE SET
+				materialized_until = EXCLUDED.materialized_until,
+				updated_at = EXCLUDED.updated_at`
+
+		if err := tx.Exec(watermarkQuery, festivalID, until).Error; err != nil {
+			return fmt.Errorf("failed to update dashboard watermark: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetAggregatedFestivalStats is GetFestivalStats with the transaction and stand figures
+// read from the dashboard aggregates
+func (r *repository) GetAggregatedFestivalStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*FestivalStats, error) {
+	cal := r.festivalCalendar(ctx, festivalID)
+	stats := &FestivalStats{
+		FestivalID:  festivalID,
+		Timeframe:   timeframe,
+		Timezone:    cal.Location.String(),
+		GeneratedAt: time.Now().In(cal.Location),
+	}
+
+	startTime := timeframe.StartTime(cal)
+	if err := r.fillTicketAndWalletStats(ctx, stats, festivalID, startTime); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			COALESCE(SUM(a.transactions), 0) as total_transactions,
+			COALESCE(SUM(a.top_ups), 0) as total_top_ups,
+			COALESCE(SUM(a.purchases), 0) as total_purchases,
+			COALESCE(SUM(a.volume), 0) as volume,
+			COUNT(DISTINCT CASE WHEN a.transactions > 0 AND a.stand_id <> '00000000-0000-0000-0000-000000000000'::uuid THEN a.stand_id END) as active_stands,
+			(SELECT COUNT(*) FROM public.stands WHERE festival_id = ?) as total_stands
+		FROM public.dashboard_minute_aggregates a
+		WHERE a.festival_id = ? AND a.bucket >= ?`
+
+	var totals struct {
+		TotalTransactions int
+		TotalTopUps       int64
+		TotalPurchases    int64
+		Volume            int64
+		ActiveStands      int
+		TotalStands       int
+	}
+	if err := r.db.WithContext(ctx).Raw(query, festivalID, festivalID, startTime.Truncate(time.Minute)).Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get aggregated transaction stats: %w", err)
+	}
+
+	stats.TotalTransactions = totals.TotalTransactions
+	stats.TotalTopUps = totals.TotalTopUps
+	stats.TotalPurchases = totals.TotalPurchases
+	if totals.TotalTransactions > 0 {
+		stats.AverageTransaction = totals.Volume / int64(totals.TotalTransactions)
+	}
+	stats.TotalRevenue = totals.TotalTopUps // Revenue from top-ups
+	stats.TotalStands = totals.TotalStands
+	stats.ActiveStands = totals.ActiveStands
+
+	return stats, nil
+}
+
+// GetAggregatedDailyRevenue retrieves the daily top-ups, purchases and transaction
+// counts from the dashboard aggregates; days are the operational days of the festival
+func (r *repository) GetAggregatedDailyRevenue(ctx context.Context, festivalID uuid.UUID, startDate, endDate time.Time) ([]DailyStats, error) {
+	cal := r.festivalCalendar(ctx, festivalID)
+	rangeStart, _ := cal.Bounds(startDate)
+	_, rangeEnd := cal.Bounds(endDate)
+
+	query := `
+		WITH date_series AS (
+			SELECT generate_series(
+				?::date,
+				?::date,
+				'1 day'::interval
+			)::date as date
+		),
+		daily AS (
+			SELECT
+				((a.bucket AT TIME ZONE ?) - make_interval(mins => ?))::date as date,
+				SUM(a.top_ups) as top_ups,
+				SUM(a.purchases) as purchases,
+				SUM(a.transactions) as transactions
+			FROM public.dashboard_minute_aggregates a
+			WHERE a.festival_id = ?
+				AND a.bucket >= ?
+				AND a.bucket < ?
+			GROUP BY 1
+		)
+		SELECT
+			ds.date,
+			COALESCE(d.top_ups, 0) + COALESCE(d.purchases, 0) as revenue,
+			COALESCE(d.transactions, 0) as transactions,
+			COALESCE(d.top_ups, 0) as top_ups,
+			COALESCE(d.purchases, 0) as purchases
+		FROM date_series ds
+		LEFT JOIN daily d ON ds.date = d.date
+		ORDER BY ds.date ASC`
+
+	var results []struct {
+		Date         time.Time
+		Revenue      int64
+		Transactions int
+		TopUps       int64
+		Purchases    int64
+	}
+
+	args := []interface{}{
+		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"),
+		cal.Location.String(), cal.Offset(), festivalID, rangeStart, rangeEnd,
+	}
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get aggregated daily revenue: %w", err)
+	}
+
+	dailyStats := make([]DailyStats, len(results))
+	for i, r := range results {
+		dailyStats[i] = DailyStats{
+			Date:         tz.Date(r.Date, cal.Location),
+			Revenue:      r.Revenue,
+			Transactions: r.Transactions,
+			TopUps:       r.TopUps,
+			Purchases:    r.Purchases,
+		}
+	}
+
+	return dailyStats, nil
+}
+
+// GetAggregatedTopProducts retrieves the best selling products across all stands from
+// the hourly product aggregates; the timeframe start is rounded down to the hour
+func (r *repository) GetAggregatedTopProducts(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]ProductStats, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+
+	query := `
+		SELECT
+			a.product_id,
+			COALESCE(p.name, '') as product_name,
+			a.stand_id,
+			COALESCE(s.name, '') as stand_name,
+			SUM(a.quantity) as quantity_sold,
+			SUM(a.revenue) as revenue,
+			COALESCE(MAX(p.price), 0) as unit_price
+		FROM public.dashboard_product_aggregates a
+		LEFT JOIN public.products p ON p.id = a.product_id
+		LEFT JOIN public.stands s ON s.id = a.stand_id
+		WHERE a.festival_id = ? AND a.bucket >= ?
+		GROUP BY a.product_id, p.name, a.stand_id, s.name
+		ORDER BY quantity_sold DESC
+		LIMIT ?`
+
+	var products []ProductStats
+	if err := r.db.WithContext(ctx).Raw(query, festivalID, startTime.Truncate(time.Hour), limit).Scan(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get aggregated top products: %w", err)
+	}
+
+	return products, nil
+}
+
+// GetAggregatedTopStands retrieves the top stands by purchase revenue from the dashboard
+// aggregates. Unique customers cannot be summed across buckets and are left at zero.
+func (r *repository) GetAggregatedTopStands(ctx context.Context, festivalID uuid.UUID, limit int, timeframe Timeframe) ([]StandStats, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+
+	query := `
+		SELECT
+			s.id as stand_id,
+			s.name as stand_name,
+			COALESCE(a.revenue, 0) as revenue,
+			COALESCE(a.transactions, 0) as transactions
+		FROM public.stands s
+		LEFT JOIN (
+			SELECT stand_id, SUM(purchases) as revenue, SUM(purchase_count) as transactions
+			FROM public.dashboard_minute_aggregates
+			WHERE festival_id = ? AND bucket >= ?
+			GROUP BY stand_id
+		) a ON a.stand_id = s.id
+		WHERE s.festival_id = ?
+		ORDER BY revenue DESC
+		LIMIT ?`
+
+	var results []struct {
+		StandID      uuid.UUID
+		StandName    string
+		Revenue      int64
+		Transactions int
+	}
+
+	args := []interface{}{festivalID, startTime.Truncate(time.Minute), festivalID, limit}
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get aggregated top stands: %w", err)
+	}
+
+	stands := make([]StandStats, len(results))
+	for i, r := range results {
+		stands[i] = StandStats{
+			StandID:      r.StandID,
+			StandName:    r.StandName,
+			Revenue:      r.Revenue,
+			Transactions: r.Transactions,
+			Timeframe:    timeframe,
+		}
+		if r.Transactions > 0 {
+			stands[i].AverageTransaction = r.Revenue / int64(r.Transactions)
+		}
+	}
+
+	return stands, nil
+}
+
+// GetEdition retrieves a festival with its dates, timezone and previous edition
+func (r *repository) GetEdition(ctx context.Context, festivalID uuid.UUID) (*Edition, error) {
+	var editions []Edition
+	if err := r.db.WithContext(ctx).Raw(
+		"SELECT id, name, start_date, end_date, timezone, previous_edition_id FROM public.festivals WHERE id = ?",
+		festivalID,
+	).Scan(&editions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get edition: %w", err)
+	}
+	if len(editions) == 0 {
+		return nil, nil
+	}
+
+	return &editions[0], nil
+}
+
+// GetEditionHourlySales retrieves the purchase revenue of an edition per local date and
+// hour, from the start of its first day to the end of its last day
+func (r *repository) GetEditionHourlySales(ctx context.Context, edition *Edition) ([]EditionHourlySales, error) {
+	start, end := edition.Window()
+	timezone := edition.Location().String()
+
+	query := `
+		SELECT
+			(t.created_at AT TIME ZONE ?)::date as local_date,
+			EXTRACT(HOUR FROM t.created_at AT TIME ZONE ?)::int as local_hour,
+			SUM(ABS(t.amount)) as revenue,
+			COUNT(*) as transactions
+		FROM public.transactions t
+		INNER JOIN public.wallets w ON t.wallet_id = w.id
+		WHERE w.festival_id = ?
+			AND t.type = 'PURCHASE'
+			AND t.status = 'COMPLETED'
+			AND t.created_at >= ?
+			AND t.created_at < ?
+		GROUP BY local_date, local_hour
+		ORDER BY local_date ASC, local_hour ASC`
+
+	var sales []EditionHourlySales
+	if err := r.db.WithContext(ctx).Raw(query, timezone, timezone, edition.ID, start, end).Scan(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get edition hourly sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+// GetEditionHourlyEntries retrieves the attendees of an edition per local date and hour of
+// their first successful entry scan of the day, so each ticket counts once per day
+func (r *repository) GetEditionHourlyEntries(ctx context.Context, edition *Edition) ([]EditionHourlyEntries, error) {
+	start, end := edition.Window()
+	timezone := edition.Location().String()
+
+	query := `
+		WITH first_entries AS (
+			SELECT
+				(s.scanned_at AT TIME ZONE ?)::date as local_date,
+				MIN(s.scanned_at) as scanned_at
+			FROM public.ticket_scans s
+			WHERE s.festival_id = ?
+				AND s.scan_type = 'ENTRY'
+				AND s.result = 'SUCCESS'
+				AND s.scanned_at >= ?
+				AND s.scanned_at < ?
+			GROUP BY s.ticket_id, (s.scanned_at AT TIME ZONE ?)::date
+		)
+		SELECT
+			local_date,
+			EXTRACT(HOUR FROM scanned_at AT TIME ZONE ?)::int as local_hour,
+			COUNT(*) as entries
+		FROM first_entries
+		GROUP BY local_date, local_hour
+		ORDER BY local_date ASC, local_hour ASC`
+
+	var entries []EditionHourlyEntries
+	if err := r.db.WithContext(ctx).Raw(query, timezone, edition.ID, start, end, timezone, timezone).Scan(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get edition hourly entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetDeliveryRecords retrieves the paid orders of a festival delivered to a table or pitch,
+// or still to deliver, with their location and runner
+func (r *repository) GetDeliveryRecords(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]DeliveryRecord, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+	filter := ""
+	args := []interface{}{festivalID}
+	if !startTime.IsZero() {
+		filter = " AND o.created_at >= ?"
+		args = append(args, startTime)
+	}
+
+	query := `
+		SELECT
+			o.delivery_location_id as location_id,
+			COALESCE(o.delivery_location_label, '') as location_label,
+			COALESCE(l.zone, '') as zone,
+			o.runner_id,
+			COALESCE(u.name, 'Unknown') as runner_name,
+			o.created_at as ordered_at,
+			o.delivery_due_at as due_at,
+			o.assigned_at,
+			o.delivered_at
+		FROM public.orders o
+		LEFT JOIN public.delivery_locations l ON l.id = o.delivery_location_id
+		LEFT JOIN public.users u ON u.id = o.runner_id
+		WHERE o.festival_id = ?
+			AND o.status = 'PAID'
+			AND o.delivery_location_id IS NOT NULL
+			AND o.delivery_due_at IS NOT NULL` + filter + `
+		ORDER BY o.created_at`
+
+	var records []DeliveryRecord
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to get delivery records: %w", err)
+	}
+	return records, nil
+}
+
+// marginColumns sums the revenue and cost of goods of the items of the paid orders
+const marginColumns = `
+			COALESCE(SUM((item->>'totalPrice')::bigint), 0) as revenue,
+			COALESCE(SUM((item->>'totalPrice')::bigint) FILTER (WHERE item->>'unitCost' IS NULL), 0) as uncosted_revenue,
+			COALESCE(SUM((item->>'quantity')::bigint * (item->>'unitCost')::bigint), 0) as cost_of_goods`
+
+// GetStandMargins retrieves the revenue and cost of goods of the paid orders of each
+// stand of a festival, highest revenue first
+func (r *repository) GetStandMargins(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]Margin, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+	filter := ""
+	args := []interface{}{festivalID}
+	if !startTime.IsZero() {
+		filter = " AND o.created_at >= ?"
+		args = append(args, startTime)
+	}
+
+	query := `
+		SELECT
+			s.id as stand_id,
+			s.name as stand_name,
+			COUNT(DISTINCT o.id) as orders,` + marginColumns + `
+		FROM public.orders o
+		INNER JOIN public.stands s ON s.id = o.stand_id
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		WHERE o.festival_id = ? AND o.status = 'PAID'` + filter + `
+		GROUP BY s.id, s.name
+		ORDER BY revenue DESC, s.name`
+
+	var margins []Margin
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&margins).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stand margins: %w", err)
+	}
+	return margins, nil
+}
+
+// ListOrderMargins retrieves the revenue and cost of goods of the paid orders of a
+// festival, optionally of one stand, latest first
+func (r *repository) ListOrderMargins(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, timeframe Timeframe, offset, limit int) ([]OrderMargin, int64, error) {
+	startTime := timeframe.StartTime(r.festivalCalendar(ctx, festivalID))
+	filter := ""
+	args := []interface{}{festivalID}
+	if standID != nil {
+		filter += " AND o.stand_id = ?"
+		args = append(args, *standID)
+	}
+	if !startTime.IsZero() {
+		filter += " AND o.created_at >= ?"
+		args = append(args, startTime)
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Raw(`
+		SELECT COUNT(*) FROM public.orders o
+		WHERE o.festival_id = ? AND o.status = 'PAID'`+filter,
+		args...,
+	).Scan(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count order margins: %w", err)
+	}
+
+	query := `
+		SELECT
+			o.id as order_id,
+			o.stand_id,
+			s.name as stand_name,
+			o.created_at,` + marginColumns + `
+		FROM public.orders o
+		INNER JOIN public.stands s ON s.id = o.stand_id
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		WHERE o.festival_id = ? AND o.status = 'PAID'` + filter + `
+		GROUP BY o.id, s.name
+		ORDER BY o.created_at DESC, o.id
+		LIMIT ? OFFSET ?`
+
+	var margins []OrderMargin
+	if err := r.db.WithContext(ctx).Raw(query, append(args, limit, offset)...).Scan(&margins).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list order margins: %w", err)
+	}
+	for i := range margins {
+		margins[i].computeMargin()
+	}
+	return margins, total, nil
+}
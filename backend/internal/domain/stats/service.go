@@ -3,25 +3,63 @@ package stats
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
 	"gorm.io/gorm"
 )
 
 // Service provides business logic for stats operations
 type Service struct {
-	repo Repository
-	db   *gorm.DB
+	repo       Repository
+	db         *gorm.DB
+	staffing   StaffingConfig
+	cashiers   CashierAnomalyConfig
+	aggregates DashboardAggregateConfig
 }
 
 // NewService creates a new stats service
 func NewService(repo Repository, db *gorm.DB) *Service {
-	return &Service{repo: repo, db: db}
+	return &Service{
+		repo:       repo,
+		db:         db,
+		staffing:   DefaultStaffingConfig(),
+		cashiers:   DefaultCashierAnomalyConfig(),
+		aggregates: DefaultDashboardAggregateConfig(),
+	}
+}
+
+// SetStaffingConfig overrides the configuration used for staffing recommendations
+func (s *Service) SetStaffingConfig(cfg StaffingConfig) {
+	s.staffing = cfg
+}
+
+// SetCashierAnomalyConfig overrides the thresholds used to flag cashier anomalies
+func (s *Service) SetCashierAnomalyConfig(cfg CashierAnomalyConfig) {
+	s.cashiers = cfg
+}
+
+// SetDashboardAggregateConfig overrides the refresh and staleness settings of the dashboard aggregates
+func (s *Service) SetDashboardAggregateConfig(cfg DashboardAggregateConfig) {
+	s.aggregates = cfg
+}
+
+// Calendar returns the operational days of the festival: its timezone and the local
+// time its days start at
+func (s *Service) Calendar(ctx context.Context, festivalID uuid.UUID) tz.Calendar {
+	cal, err := s.repo.GetFestivalCalendar(ctx, festivalID)
+	if err != nil {
+		return tz.NewCalendar("", "")
+	}
+	return cal
 }
 
-// GetDashboardStats retrieves comprehensive dashboard statistics for a festival
+// GetDashboardStats retrieves comprehensive dashboard statistics for a festival. Sales
+// figures come from the materialized aggregates while they are fresh, and from the raw
+// transactions otherwise.
 func (s *Service) GetDashboardStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*DashboardStats, error) {
 	// Verify festival exists
 	var festivalExists bool
@@ -35,8 +73,16 @@ func (s *Service) GetDashboardStats(ctx context.Context, festivalID uuid.UUID, t
 		return nil, errors.ErrFestivalNotFound
 	}
 
+	aggregated := s.dashboardAggregatesFresh(ctx, festivalID)
+
 	// Get overview stats
-	festivalStats, err := s.repo.GetFestivalStats(ctx, festivalID, timeframe)
+	var festivalStats *FestivalStats
+	var err error
+	if aggregated {
+		festivalStats, err = s.repo.GetAggregatedFestivalStats(ctx, festivalID, timeframe)
+	} else {
+		festivalStats, err = s.repo.GetFestivalStats(ctx, festivalID, timeframe)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get festival stats: %w", err)
 	}
@@ -56,21 +102,38 @@ func (s *Service) GetDashboardStats(ctx context.Context, festivalID uuid.UUID, t
 		chartDays = 7
 	}
 
-	endDate := time.Now()
+	endDate := s.Calendar(ctx, festivalID).DateOf(time.Now())
 	startDate := endDate.AddDate(0, 0, -chartDays+1)
-	revenueChart, err := s.GetRevenueChart(ctx, festivalID, startDate, endDate)
+	revenueChart, err := s.revenueChart(ctx, festivalID, startDate, endDate, aggregated)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get revenue chart: %w", err)
 	}
 
 	// Get top products
-	topProducts, err := s.GetTopSellingProducts(ctx, festivalID, 10, timeframe)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get top products: %w", err)
+	var topProducts []ProductStatsResponse
+	if aggregated {
+		products, err := s.repo.GetAggregatedTopProducts(ctx, festivalID, 10, timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top products: %w", err)
+		}
+		topProducts = make([]ProductStatsResponse, len(products))
+		for i, p := range products {
+			topProducts[i] = p.ToResponse()
+		}
+	} else {
+		topProducts, err = s.GetTopSellingProducts(ctx, festivalID, 10, timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top products: %w", err)
+		}
 	}
 
 	// Get top stands
-	topStands, err := s.repo.GetTopStands(ctx, festivalID, 5, timeframe)
+	var topStands []StandStats
+	if aggregated {
+		topStands, err = s.repo.GetAggregatedTopStands(ctx, festivalID, 5, timeframe)
+	} else {
+		topStands, err = s.repo.GetTopStands(ctx, festivalID, 5, timeframe)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get top stands: %w", err)
 	}
@@ -104,12 +167,24 @@ func (s *Service) GetDashboardStats(ctx context.Context, festivalID uuid.UUID, t
 
 // GetRevenueChart retrieves revenue chart data for a date range
 func (s *Service) GetRevenueChart(ctx context.Context, festivalID uuid.UUID, startDate, endDate time.Time) (*RevenueChartData, error) {
-	dailyStats, err := s.repo.GetDailyStats(ctx, festivalID, startDate, endDate)
+	return s.revenueChart(ctx, festivalID, startDate, endDate, s.dashboardAggregatesFresh(ctx, festivalID))
+}
+
+// revenueChart builds the revenue chart from the dashboard aggregates or the raw daily stats
+func (s *Service) revenueChart(ctx context.Context, festivalID uuid.UUID, startDate, endDate time.Time, aggregated bool) (*RevenueChartData, error) {
+	var dailyStats []DailyStats
+	var err error
+	if aggregated {
+		dailyStats, err = s.repo.GetAggregatedDailyRevenue(ctx, festivalID, startDate, endDate)
+	} else {
+		dailyStats, err = s.repo.GetDailyStats(ctx, festivalID, startDate, endDate)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily stats: %w", err)
 	}
 
 	chartData := &RevenueChartData{
+		Timezone:  s.Calendar(ctx, festivalID).Location.String(),
 		Labels:    make([]string, len(dailyStats)),
 		Revenue:   make([]int64, len(dailyStats)),
 		TopUps:    make([]int64, len(dailyStats)),
@@ -148,6 +223,62 @@ func (s *Service) GetTopSellingProducts(ctx context.Context, festivalID uuid.UUI
 	return response, nil
 }
 
+// GetRevenueByCategory retrieves revenue per product category, rolling subcategory
+// revenue up into every ancestor category, most revenue first
+func (s *Service) GetRevenueByCategory(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]CategoryRevenue, error) {
+	categories, err := s.repo.GetRevenueByCategory(ctx, festivalID, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by category: %w", err)
+	}
+
+	// Categories of the tree without any sale, even below them, are left out
+	categories = RollUpCategoryRevenue(categories)
+	sold := make([]CategoryRevenue, 0, len(categories))
+	for _, c := range categories {
+		if c.TotalRevenue != 0 || c.QuantitySold != 0 {
+			sold = append(sold, c)
+		}
+	}
+	sort.SliceStable(sold, func(i, j int) bool {
+		return sold[i].TotalRevenue > sold[j].TotalRevenue
+	})
+	return sold, nil
+}
+
+// RollUpCategoryRevenue computes TotalRevenue for each category as its direct revenue
+// plus the direct revenue of all descendants found in the list
+func RollUpCategoryRevenue(categories []CategoryRevenue) []CategoryRevenue {
+	index := make(map[uuid.UUID]int, len(categories))
+	for i, c := range categories {
+		categories[i].TotalRevenue = c.DirectRevenue
+		if c.CategoryID != nil {
+			index[*c.CategoryID] = i
+		}
+	}
+
+	for _, c := range categories {
+		if c.DirectRevenue == 0 {
+			continue
+		}
+		// Walk up the ancestors, bounded to protect against corrupt cycles
+		parent := c.ParentID
+		for depth := 0; parent != nil && depth < len(categories); depth++ {
+			i, ok := index[*parent]
+			if !ok {
+				break
+			}
+			categories[i].TotalRevenue += c.DirectRevenue
+			parent = categories[i].ParentID
+		}
+	}
+
+	for i := range categories {
+		categories[i].RevenueDisplay = formatCurrency(categories[i].TotalRevenue)
+	}
+
+	return categories
+}
+
 // GetStaffPerformance retrieves performance statistics for all staff at a festival
 func (s *Service) GetStaffPerformance(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]StaffPerformanceResponse, error) {
 	// Verify festival exists
@@ -175,6 +306,246 @@ func (s *Service) GetStaffPerformance(ctx context.Context, festivalID uuid.UUID,
 	return response, nil
 }
 
+// GenerateStaffingRecommendations recomputes and stores the staffing recommendations of a festival
+func (s *Service) GenerateStaffingRecommendations(ctx context.Context, festivalID uuid.UUID) ([]StaffingRecommendation, error) {
+	now := time.Now()
+	hours, err := s.repo.GetHourlyStaffing(ctx, festivalID, now.Add(-s.staffing.Lookback))
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := RecommendStaffing(festivalID, hours, s.staffing, now)
+	if err := s.repo.ReplaceStaffingRecommendations(ctx, festivalID, recommendations); err != nil {
+		return nil, err
+	}
+
+	return recommendations, nil
+}
+
+// GetStaffingRecommendations retrieves the staffing recommendations of a festival,
+// recomputing them first when refresh is set
+func (s *Service) GetStaffingRecommendations(ctx context.Context, festivalID uuid.UUID, refresh bool) ([]StaffingRecommendationResponse, error) {
+	// Verify festival exists
+	var festivalExists bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.festivals WHERE id = ?)",
+		festivalID,
+	).Scan(&festivalExists).Error; err != nil {
+		return nil, fmt.Errorf("failed to check festival existence: %w", err)
+	}
+	if !festivalExists {
+		return nil, errors.ErrFestivalNotFound
+	}
+
+	var recommendations []StaffingRecommendation
+	var err error
+	if refresh {
+		recommendations, err = s.GenerateStaffingRecommendations(ctx, festivalID)
+	} else {
+		recommendations, err = s.repo.GetStaffingRecommendations(ctx, festivalID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staffing recommendations: %w", err)
+	}
+
+	response := make([]StaffingRecommendationResponse, len(recommendations))
+	for i := range recommendations {
+		response[i] = recommendations[i].ToResponse()
+	}
+
+	return response, nil
+}
+
+// GetWeatherImpact compares the festival revenue in wet and dry hours
+func (s *Service) GetWeatherImpact(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*WeatherImpact, error) {
+	// Verify festival exists
+	var festivalExists bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.festivals WHERE id = ?)",
+		festivalID,
+	).Scan(&festivalExists).Error; err != nil {
+		return nil, fmt.Errorf("failed to check festival existence: %w", err)
+	}
+	if !festivalExists {
+		return nil, errors.ErrFestivalNotFound
+	}
+
+	hours, err := s.repo.GetHourlyWeatherRevenue(ctx, festivalID, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weather impact: %w", err)
+	}
+
+	return ComputeWeatherImpact(festivalID, timeframe, hours), nil
+}
+
+// GetEditionComparison lines up the sales of a festival with previous editions. Without
+// requested editions, the chain of previous editions is followed.
+func (s *Service) GetEditionComparison(ctx context.Context, festivalID uuid.UUID, editionIDs []uuid.UUID, alignment ComparisonAlignment) (*EditionComparison, error) {
+	current, err := s.editionData(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(editionIDs) == 0 {
+		seen := map[uuid.UUID]bool{festivalID: true}
+		next := current.Edition.PreviousEditionID
+		for next != nil && !seen[*next] && len(editionIDs) < DefaultComparedEditions {
+			seen[*next] = true
+			editionIDs = append(editionIDs, *next)
+
+			edition, err := s.repo.GetEdition(ctx, *next)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get previous edition: %w", err)
+			}
+			if edition == nil {
+				editionIDs = editionIDs[:len(editionIDs)-1]
+				break
+			}
+			next = edition.PreviousEditionID
+		}
+	}
+
+	previous := make([]EditionData, 0, len(editionIDs))
+	for _, id := range editionIDs {
+		if id == festivalID {
+			continue
+		}
+		data, err := s.editionData(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, *data)
+	}
+
+	return CompareEditions(*current, previous, alignment, time.Now()), nil
+}
+
+// editionData loads an edition with its hourly sales and entries
+func (s *Service) editionData(ctx context.Context, festivalID uuid.UUID) (*EditionData, error) {
+	edition, err := s.repo.GetEdition(ctx, festivalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edition: %w", err)
+	}
+	if edition == nil {
+		return nil, errors.ErrFestivalNotFound
+	}
+
+	sales, err := s.repo.GetEditionHourlySales(ctx, edition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edition comparison: %w", err)
+	}
+	entries, err := s.repo.GetEditionHourlyEntries(ctx, edition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edition comparison: %w", err)
+	}
+
+	return &EditionData{Edition: *edition, Sales: sales, Entries: entries}, nil
+}
+
+// GetCashierAnalytics computes the per-cashier analytics of a festival
+func (s *Service) GetCashierAnalytics(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*CashierAnalytics, error) {
+	// Verify festival exists
+	var festivalExists bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.festivals WHERE id = ?)",
+		festivalID,
+	).Scan(&festivalExists).Error; err != nil {
+		return nil, fmt.Errorf("failed to check festival existence: %w", err)
+	}
+	if !festivalExists {
+		return nil, errors.ErrFestivalNotFound
+	}
+
+	activity, err := s.repo.GetCashierActivity(ctx, festivalID, nil, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cashier analytics: %w", err)
+	}
+
+	return ComputeCashierAnalytics(festivalID, nil, timeframe, activity, s.cashiers, time.Now()), nil
+}
+
+// GetDeliveryAnalytics computes the SLA compliance of the table and pitch deliveries of
+// a festival
+func (s *Service) GetDeliveryAnalytics(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*DeliveryAnalytics, error) {
+	var festivalExists bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.festivals WHERE id = ?)",
+		festivalID,
+	).Scan(&festivalExists).Error; err != nil {
+		return nil, fmt.Errorf("failed to check festival existence: %w", err)
+	}
+	if !festivalExists {
+		return nil, errors.ErrFestivalNotFound
+	}
+
+	records, err := s.repo.GetDeliveryRecords(ctx, festivalID, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery analytics: %w", err)
+	}
+
+	return ComputeDeliveryAnalytics(festivalID, timeframe, records, time.Now()), nil
+}
+
+// GetMarginAnalytics computes the gross margin of the paid orders of a festival, in
+// total and by stand
+func (s *Service) GetMarginAnalytics(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*MarginAnalytics, error) {
+	var festivalExists bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.festivals WHERE id = ?)",
+		festivalID,
+	).Scan(&festivalExists).Error; err != nil {
+		return nil, fmt.Errorf("failed to check festival existence: %w", err)
+	}
+	if !festivalExists {
+		return nil, errors.ErrFestivalNotFound
+	}
+
+	stands, err := s.repo.GetStandMargins(ctx, festivalID, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get margin analytics: %w", err)
+	}
+
+	return ComputeMargins(festivalID, timeframe, stands, time.Now()), nil
+}
+
+// ListOrderMargins lists the gross margin of the paid orders of a festival, optionally
+// of one stand
+func (s *Service) ListOrderMargins(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, timeframe Timeframe, offset, limit int) ([]OrderMargin, int64, error) {
+	var festivalExists bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS(SELECT 1 FROM public.festivals WHERE id = ?)",
+		festivalID,
+	).Scan(&festivalExists).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to check festival existence: %w", err)
+	}
+	if !festivalExists {
+		return nil, 0, errors.ErrFestivalNotFound
+	}
+	return s.repo.ListOrderMargins(ctx, festivalID, standID, timeframe, offset, limit)
+}
+
+// GetStandCashierAnalytics computes the per-cashier analytics of a stand, comparing
+// its cashiers with each other
+func (s *Service) GetStandCashierAnalytics(ctx context.Context, standID uuid.UUID, timeframe Timeframe) (*CashierAnalytics, error) {
+	var festivalIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT festival_id FROM public.stands WHERE id = ?",
+		standID,
+	).Scan(&festivalIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(festivalIDs) == 0 {
+		return nil, errors.ErrNotFound
+	}
+
+	activity, err := s.repo.GetCashierActivity(ctx, festivalIDs[0], &standID, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cashier analytics: %w", err)
+	}
+
+	return ComputeCashierAnalytics(festivalIDs[0], &standID, timeframe, activity, s.cashiers, time.Now()), nil
+}
+
 // GetFestivalStats retrieves overall statistics for a festival
 func (s *Service) GetFestivalStats(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) (*FestivalStatsResponse, error) {
 	// Verify festival exists
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// categoryRevenueRepository serves the category rows of GetRevenueByCategory
+type categoryRevenueRepository struct {
+	Repository
+	categories []CategoryRevenue
+}
+
+func (r *categoryRevenueRepository) GetRevenueByCategory(ctx context.Context, festivalID uuid.UUID, timeframe Timeframe) ([]CategoryRevenue, error) {
+	return append([]CategoryRevenue(nil), r.categories...), nil
+}
+
+// categoryTree returns the rows of a festival product tree:
+//
+//	Drinks
+//	├── Beers
+//	│   ├── Lagers (sold 1200)
+//	│   └── IPAs (sold 800)
+//	└── Softs (sold 300)
+//	Food (no sale below it)
+//
+// plus the sales of uncategorized products, in the order the query returns them
+func categoryTree() (map[string]uuid.UUID, []CategoryRevenue) {
+	ids := map[string]uuid.UUID{}
+	for _, name := range []string{"Drinks", "Beers", "Lagers", "IPAs", "Softs", "Food"} {
+		ids[name] = uuid.New()
+	}
+	row := func(name, parent string, quantity int, revenue int64) CategoryRevenue {
+		id := ids[name]
+		c := CategoryRevenue{CategoryID: &id, CategoryName: name, QuantitySold: quantity, DirectRevenue: revenue}
+		if parent != "" {
+			parentID := ids[parent]
+			c.ParentID = &parentID
+		}
+		return c
+	}
+
+	return ids, []CategoryRevenue{
+		row("Lagers", "Beers", 3, 1200),
+		row("IPAs", "Beers", 2, 800),
+		row("Softs", "Drinks", 1, 300),
+		// Neither parent has direct sales
+		row("Drinks", "", 0, 0),
+		row("Beers", "Drinks", 0, 0),
+		row("Food", "", 0, 0),
+		{CategoryName: "Uncategorized", QuantitySold: 1, DirectRevenue: 450},
+	}
+}
+
+func TestRollUpCategoryRevenue(t *testing.T) {
+	ids, categories := categoryTree()
+
+	totals := map[string]int64{}
+	for _, c := range RollUpCategoryRevenue(categories) {
+		totals[c.CategoryName] = c.TotalRevenue
+	}
+
+	assert.Equal(t, int64(1200), totals["Lagers"])
+	assert.Equal(t, int64(800), totals["IPAs"])
+	assert.Equal(t, int64(2000), totals["Beers"], "parent without direct sales gets its children")
+	assert.Equal(t, int64(2300), totals["Drinks"], "grandparent gets every level below it")
+	assert.Equal(t, int64(0), totals["Food"])
+	assert.Equal(t, int64(450), totals["Uncategorized"])
+
+	t.Run("parent listed after its children", func(t *testing.T) {
+		_, categories := categoryTree()
+		// Reversed, so that every ancestor comes after its descendants
+		for i, j := 0, len(categories)-1; i < j; i, j = i+1, j-1 {
+			categories[i], categories[j] = categories[j], categories[i]
+		}
+		for _, c := range RollUpCategoryRevenue(categories) {
+			if c.CategoryID != nil && *c.CategoryID == ids["Drinks"] {
+				assert.Equal(t, int64(2300), c.TotalRevenue)
+			}
+		}
+	})
+
+	t.Run("cycle does not loop forever", func(t *testing.T) {
+		a, b := uuid.New(), uuid.New()
+		categories := []CategoryRevenue{
+			{CategoryID: &a, ParentID: &b, DirectRevenue: 100},
+			{CategoryID: &b, ParentID: &a, DirectRevenue: 50},
+		}
+		rolled := RollUpCategoryRevenue(categories)
+		assert.Greater(t, rolled[0].TotalRevenue, int64(100))
+	})
+}
+
+func TestService_GetRevenueByCategory(t *testing.T) {
+	_, categories := categoryTree()
+	service := NewService(&categoryRevenueRepository{categories: categories}, nil)
+
+	revenue, err := service.GetRevenueByCategory(context.Background(), uuid.New(), TimeframeAll)
+	require.NoError(t, err)
+
+	var names []string
+	for _, c := range revenue {
+		names = append(names, c.CategoryName)
+	}
+	// Most revenue first, with the categories without any sale below them left out
+	assert.Equal(t, []string{"Drinks", "Beers", "Lagers", "IPAs", "Uncategorized", "Softs"}, names)
+	assert.Equal(t, "23 EUR", revenue[0].RevenueDisplay)
+}
@@ -0,0 +1,283 @@
+package stats
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrFunnelStepOutOfRange is returned for a drop-off step that is not a step of the
+// funnel after the first
+var ErrFunnelStepOutOfRange = errors.New("funnel step out of range")
+
+// MaxTimelineEvents caps the events of a session timeline; longer sessions are truncated
+// to their first events
+const MaxTimelineEvents = 2000
+
+// TimelineOrderMargin is how long after the last event of a session its orders are
+// still shown, as the order may be confirmed after the app stops sending events
+const TimelineOrderMargin = 5 * time.Minute
+
+// Kinds of timeline entries
+const (
+	TimelineKindEvent  = "event"
+	TimelineKindScreen = "screen" // Page views, with the screen viewed
+	TimelineKindOrder  = "order"
+)
+
+// Linked entity types, and the event data keys referencing them
+const (
+	EntityStand      = "stand"
+	EntityProduct    = "product"
+	EntityOrder      = "order"
+	EntityTicketType = "ticket_type"
+	EntityArtist     = "artist"
+)
+
+// entityDataKeys are the event data keys referencing an entity, and its type
+var entityDataKeys = []struct {
+	key    string
+	entity string
+}{
+	{"standId", EntityStand},
+	{"productId", EntityProduct},
+	{"orderId", EntityOrder},
+	{"ticketTypeId", EntityTicketType},
+	{"artistId", EntityArtist},
+}
+
+// TimelineEntity is an entity referenced by a timeline entry
+type TimelineEntity struct {
+	Type   string    `json:"type"`
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name,omitempty"`
+	Amount *int64    `json:"amount,omitempty"` // In cents: the price of a product, the total of an order
+	Status string    `json:"status,omitempty"` // Of an order
+	Found  bool      `json:"found"`            // False when the entity was deleted or belongs to another festival
+}
+
+// TimelineOrder is an order placed by the user of a session
+type TimelineOrder struct {
+	ID            uuid.UUID `json:"id"`
+	StandID       uuid.UUID `json:"standId"`
+	TotalAmount   int64     `json:"totalAmount"`
+	Status        string    `json:"status"`
+	PaymentMethod string    `json:"paymentMethod"`
+	Items         int       `json:"items"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// TimelineEntry is an event, screen view or order of a session
+type TimelineEntry struct {
+	At         time.Time              `json:"at"`
+	OffsetMs   int64                  `json:"offsetMs"` // Since the start of the session
+	Kind       string                 `json:"kind"`
+	ID         uuid.UUID              `json:"id"` // Of the event or order
+	Type       string                 `json:"type"`
+	Screen     string                 `json:"screen,omitempty"`
+	Category   string                 `json:"category,omitempty"`
+	Action     string                 `json:"action,omitempty"`
+	Label      string                 `json:"label,omitempty"`
+	Value      float64                `json:"value,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	FunnelStep *int                   `json:"funnelStep,omitempty"` // Index of the funnel step this event completed
+	Links      []TimelineEntity       `json:"links,omitempty"`
+}
+
+// TimelineFunnel is the progress of a session through a funnel
+type TimelineFunnel struct {
+	Name         string   `json:"name"`
+	Steps        []string `json:"steps"`
+	Reached      int      `json:"reached"` // Steps completed, in order
+	Completed    bool     `json:"completed"`
+	DroppedAfter string   `json:"droppedAfter,omitempty"` // Last step completed before the drop-off
+	NextStep     string   `json:"nextStep,omitempty"`     // Step never reached
+}
+
+// SessionTimeline is the journey of a session rebuilt from its events and orders,
+// ordered to the millisecond
+type SessionTimeline struct {
+	FestivalID uuid.UUID       `json:"festivalId"`
+	SessionID  string          `json:"sessionId"`
+	UserID     *uuid.UUID      `json:"userId,omitempty"`
+	Platform   string          `json:"platform,omitempty"`
+	AppVersion string          `json:"appVersion,omitempty"`
+	StartedAt  time.Time       `json:"startedAt"`
+	EndedAt    time.Time       `json:"endedAt"`
+	DurationMs int64           `json:"durationMs"`
+	Events     int             `json:"events"`
+	Screens    int             `json:"screens"`
+	Orders     int             `json:"orders"`
+	Truncated  bool            `json:"truncated"` // More than MaxTimelineEvents events
+	Funnel     *TimelineFunnel `json:"funnel,omitempty"`
+	Entries    []TimelineEntry `json:"entries"`
+}
+
+// DroppedSession is a session that completed a funnel step and never the next one
+type DroppedSession struct {
+	SessionID     string     `json:"sessionId"`
+	UserID        *uuid.UUID `json:"userId,omitempty"`
+	ReachedAt     time.Time  `json:"reachedAt"` // When the last step was completed
+	LastEventAt   time.Time  `json:"lastEventAt"`
+	LastEventType string     `json:"lastEventType"`
+	EventsAfter   int        `json:"eventsAfter"` // Events of the session after the last step
+}
+
+// FunnelDropOffs lists the sessions that left a funnel after a step
+type FunnelDropOffs struct {
+	Funnel   string           `json:"funnel"`
+	Step     int              `json:"step"` // Index of the step not reached
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	Start    time.Time        `json:"start"`
+	End      time.Time        `json:"end"`
+	Sessions []DroppedSession `json:"sessions"`
+}
+
+// entityRefs returns the entities referenced by the data of the events and by the
+// orders, by type
+func entityRefs(events []AnalyticsEvent, orders []TimelineOrder) map[string][]uuid.UUID {
+	refs := make(map[string][]uuid.UUID)
+	seen := make(map[string]bool)
+	add := func(entityType string, id uuid.UUID) {
+		key := entityType + ":" + id.String()
+		if id == uuid.Nil || seen[key] {
+			return
+		}
+		seen[key] = true
+		refs[entityType] = append(refs[entityType], id)
+	}
+
+	for _, event := range events {
+		for _, ref := range entityDataKeys {
+			if raw, ok := event.Data[ref.key].(string); ok {
+				if id, err := uuid.Parse(raw); err == nil {
+					add(ref.entity, id)
+				}
+			}
+		}
+	}
+	for _, order := range orders {
+		add(EntityStand, order.StandID)
+	}
+	return refs
+}
+
+// buildSessionTimeline merges the events and orders of a session into one timeline.
+// Entries at the same millisecond keep the order the events were received in, and an
+// order comes after the events of its millisecond. A funnel, when given, marks the
+// events completing each of its steps in order.
+func buildSessionTimeline(festivalID uuid.UUID, sessionID string, events []AnalyticsEvent, orders []TimelineOrder, entities []TimelineEntity, funnel *TimelineFunnel) *SessionTimeline {
+	timeline := &SessionTimeline{
+		FestivalID: festivalID,
+		SessionID:  sessionID,
+		Entries:    []TimelineEntry{},
+		Funnel:     funnel,
+	}
+
+	byKey := make(map[string]TimelineEntity, len(entities))
+	for _, entity := range entities {
+		byKey[entity.Type+":"+entity.ID.String()] = entity
+	}
+	link := func(entityType string, id uuid.UUID) TimelineEntity {
+		if entity, ok := byKey[entityType+":"+id.String()]; ok {
+			return entity
+		}
+		return TimelineEntity{Type: entityType, ID: id}
+	}
+
+	for _, event := range events {
+		if timeline.UserID == nil && event.UserID != nil {
+			timeline.UserID = event.UserID
+		}
+		if event.Platform != "" {
+			timeline.Platform = event.Platform
+		}
+		if event.AppVersion != "" {
+			timeline.AppVersion = event.AppVersion
+		}
+
+		entry := TimelineEntry{
+			At:       event.Timestamp,
+			Kind:     TimelineKindEvent,
+			ID:       event.ID,
+			Type:     string(event.Type),
+			Category: event.Category,
+			Action:   event.Action,
+			Label:    event.Label,
+			Value:    event.Value,
+			Data:     event.Data,
+		}
+		if event.Type == EventTypePageView {
+			entry.Kind = TimelineKindScreen
+			entry.Screen = event.Label
+			if screen, ok := event.Data["screen"].(string); ok && screen != "" {
+				entry.Screen = screen
+			}
+			timeline.Screens++
+		} else {
+			timeline.Events++
+		}
+
+		for _, ref := range entityDataKeys {
+			if raw, ok := event.Data[ref.key].(string); ok {
+				if id, err := uuid.Parse(raw); err == nil {
+					entry.Links = append(entry.Links, link(ref.entity, id))
+				}
+			}
+		}
+
+		if funnel != nil && funnel.Reached < len(funnel.Steps) && string(event.Type) == funnel.Steps[funnel.Reached] {
+			step := funnel.Reached
+			entry.FunnelStep = &step
+			funnel.Reached++
+		}
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+
+	for _, order := range orders {
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			At:    order.CreatedAt,
+			Kind:  TimelineKindOrder,
+			ID:    order.ID,
+			Type:  order.Status,
+			Label: order.PaymentMethod,
+			Value: float64(order.TotalAmount),
+			Data:  map[string]interface{}{"items": order.Items},
+			Links: []TimelineEntity{link(EntityStand, order.StandID)},
+		})
+		timeline.Orders++
+	}
+
+	// Events come sorted from the repository; the orders are merged in by millisecond
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		a, b := timeline.Entries[i].At.Truncate(time.Millisecond), timeline.Entries[j].At.Truncate(time.Millisecond)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return timeline.Entries[i].Kind != TimelineKindOrder && timeline.Entries[j].Kind == TimelineKindOrder
+	})
+
+	if len(timeline.Entries) > 0 {
+		timeline.StartedAt = timeline.Entries[0].At
+		timeline.EndedAt = timeline.Entries[len(timeline.Entries)-1].At
+		timeline.DurationMs = timeline.EndedAt.Sub(timeline.StartedAt).Milliseconds()
+		for i := range timeline.Entries {
+			timeline.Entries[i].OffsetMs = timeline.Entries[i].At.Sub(timeline.StartedAt).Milliseconds()
+		}
+	}
+
+	if funnel != nil {
+		funnel.Completed = funnel.Reached == len(funnel.Steps)
+		if !funnel.Completed {
+			funnel.NextStep = funnel.Steps[funnel.Reached]
+			if funnel.Reached > 0 {
+				funnel.DroppedAfter = funnel.Steps[funnel.Reached-1]
+			}
+		}
+	}
+
+	return timeline
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSessionTimeline(t *testing.T) {
+	festivalID := uuid.New()
+	userID := uuid.New()
+	standID := uuid.New()
+	productID := uuid.New()
+	start := time.Date(2026, 7, 18, 21, 0, 0, 0, time.UTC)
+
+	events := []AnalyticsEvent{
+		{ID: uuid.New(), Type: EventTypePageView, Label: "Lineup", Timestamp: start},
+		{ID: uuid.New(), Type: EventTypePageView, UserID: &userID, Platform: "ios", Data: map[string]interface{}{"screen": "StandMenu", "standId": standID.String()}, Timestamp: start.Add(2 * time.Second)},
+		{ID: uuid.New(), Type: EventTypeWalletTopUp, Data: map[string]interface{}{"productId": productID.String()}, Timestamp: start.Add(5 * time.Second)},
+		// Same millisecond as the order below
+		{ID: uuid.New(), Type: EventTypePurchase, Timestamp: start.Add(9*time.Second + 400*time.Microsecond)},
+	}
+	orders := []TimelineOrder{
+		{ID: uuid.New(), StandID: standID, TotalAmount: 1250, Status: "COMPLETED", PaymentMethod: "wallet", Items: 2, CreatedAt: start.Add(9 * time.Second)},
+	}
+	entities := []TimelineEntity{{Type: EntityStand, ID: standID, Name: "Main Bar", Found: true}}
+
+	funnel := &TimelineFunnel{Name: "purchase", Steps: []string{string(EventTypePageView), string(EventTypeWalletTopUp), string(EventTypePurchase), string(EventTypeCheckIn)}}
+	timeline := buildSessionTimeline(festivalID, "s-1", events, orders, entities, funnel)
+
+	require.Len(t, timeline.Entries, 5)
+	assert.Equal(t, &userID, timeline.UserID)
+	assert.Equal(t, "ios", timeline.Platform)
+	assert.Equal(t, 2, timeline.Screens)
+	assert.Equal(t, 2, timeline.Events)
+	assert.Equal(t, 1, timeline.Orders)
+	assert.Equal(t, int64(9000), timeline.DurationMs)
+
+	assert.Equal(t, TimelineKindScreen, timeline.Entries[0].Kind)
+	assert.Equal(t, "Lineup", timeline.Entries[0].Screen)
+	assert.Equal(t, "StandMenu", timeline.Entries[1].Screen)
+	require.Len(t, timeline.Entries[1].Links, 1)
+	assert.Equal(t, "Main Bar", timeline.Entries[1].Links[0].Name)
+
+	// Unknown entities are linked but not found
+	require.Len(t, timeline.Entries[2].Links, 1)
+	assert.False(t, timeline.Entries[2].Links[0].Found)
+
+	// The order comes after the event of its millisecond
+	assert.Equal(t, string(EventTypePurchase), timeline.Entries[3].Type)
+	assert.Equal(t, TimelineKindOrder, timeline.Entries[4].Kind)
+	assert.Equal(t, int64(9000), timeline.Entries[4].OffsetMs)
+
+	// Only the first page view completes the first step
+	require.NotNil(t, timeline.Entries[0].FunnelStep)
+	assert.Equal(t, 0, *timeline.Entries[0].FunnelStep)
+	assert.Nil(t, timeline.Entries[1].FunnelStep)
+	assert.Equal(t, 2, *timeline.Entries[3].FunnelStep)
+
+	assert.Equal(t, 3, funnel.Reached)
+	assert.False(t, funnel.Completed)
+	assert.Equal(t, string(EventTypePurchase), funnel.DroppedAfter)
+	assert.Equal(t, string(EventTypeCheckIn), funnel.NextStep)
+}
+
+func TestEntityRefs(t *testing.T) {
+	standID := uuid.New()
+	artistID := uuid.New()
+
+	refs := entityRefs([]AnalyticsEvent{
+		{Data: map[string]interface{}{"standId": standID.String(), "artistId": artistID.String()}},
+		{Data: map[string]interface{}{"standId": standID.String(), "productId": "not-a-uuid"}},
+	}, []TimelineOrder{{StandID: standID}})
+
+	assert.Equal(t, []uuid.UUID{standID}, refs[EntityStand])
+	assert.Equal(t, []uuid.UUID{artistID}, refs[EntityArtist])
+	assert.Empty(t, refs[EntityProduct])
+}
@@ -0,0 +1,230 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// Task type for the periodic staffing recommendation job
+const TypeGenerateStaffingRecommendations = "analytics:staffing_recommendations"
+
+// GenerateStaffingPayload limits the job to one festival; all festivals with recent orders otherwise
+type GenerateStaffingPayload struct {
+	FestivalID *uuid.UUID `json:"festivalId,omitempty"`
+}
+
+// NewGenerateStaffingRecommendationsTask creates a task that recomputes staffing recommendations
+func NewGenerateStaffingRecommendationsTask(payload GenerateStaffingPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeGenerateStaffingRecommendations, data), nil
+}
+
+// StaffingConfig configures how staffing recommendations are computed
+type StaffingConfig struct {
+	Lookback                 time.Duration // Order history used to build the hourly profile
+	TargetOrdersPerStaffHour float64       // Orders one cashier is expected to handle per hour
+}
+
+// DefaultStaffingConfig returns the default staffing configuration
+func DefaultStaffingConfig() StaffingConfig {
+	return StaffingConfig{
+		Lookback:                 14 * 24 * time.Hour,
+		TargetOrdersPerStaffHour: 30,
+	}
+}
+
+// HourlyStaffing is the average order volume and staffing of a stand for an hour of the day
+type HourlyStaffing struct {
+	StandID     uuid.UUID `gorm:"column:stand_id"`
+	StandName   string    `gorm:"column:stand_name"`
+	Hour        int       `gorm:"column:hour"`         // Hour of day in the festival timezone (0-23)
+	Days        int       `gorm:"column:days"`         // Number of days with orders at this hour
+	AvgOrders   float64   `gorm:"column:avg_orders"`   // Average paid orders during the hour
+	AvgStaff    float64   `gorm:"column:avg_staff"`    // Average distinct staff processing orders during the hour
+	RosterStaff int       `gorm:"column:roster_staff"` // Cashiers and managers assigned to the stand
+}
+
+// StaffingRecommendation suggests additional staff for a stand over a range of hours
+type StaffingRecommendation struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID       uuid.UUID `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID          uuid.UUID `json:"standId" gorm:"type:uuid;not null"`
+	StandName        string    `json:"standName"`
+	StartHour        int       `json:"startHour"`
+	EndHour          int       `json:"endHour"` // Exclusive, 24 means midnight
+	CurrentStaff     int       `json:"currentStaff"`
+	RecommendedStaff int       `json:"recommendedStaff"`
+	AdditionalStaff  int       `json:"additionalStaff"`
+	AvgOrdersPerHour float64   `json:"avgOrdersPerHour"`
+	Message          string    `json:"message"`
+	GeneratedAt      time.Time `json:"generatedAt"`
+}
+
+func (StaffingRecommendation) TableName() string {
+	return "staffing_recommendations"
+}
+
+// StaffingRecommendationResponse represents the API response for a staffing recommendation
+type StaffingRecommendationResponse struct {
+	StandID          uuid.UUID `json:"standId"`
+	StandName        string    `json:"standName"`
+	Window           string    `json:"window"`
+	CurrentStaff     int       `json:"currentStaff"`
+	RecommendedStaff int       `json:"recommendedStaff"`
+	AdditionalStaff  int       `json:"additionalStaff"`
+	AvgOrdersPerHour float64   `json:"avgOrdersPerHour"`
+	Message          string    `json:"message"`
+	GeneratedAt      string    `json:"generatedAt"`
+}
+
+// ToResponse converts a StaffingRecommendation to its API response
+func (r *StaffingRecommendation) ToResponse() StaffingRecommendationResponse {
+	return StaffingRecommendationResponse{
+		StandID:          r.StandID,
+		StandName:        r.StandName,
+		Window:           formatHourRange(r.StartHour, r.EndHour),
+		CurrentStaff:     r.CurrentStaff,
+		RecommendedStaff: r.RecommendedStaff,
+		AdditionalStaff:  r.AdditionalStaff,
+		AvgOrdersPerHour: math.Round(r.AvgOrdersPerHour*10) / 10,
+		Message:          r.Message,
+		GeneratedAt:      r.GeneratedAt.Format(time.RFC3339),
+	}
+}
+
+// RecommendStaffing compares the hourly order volume of each stand with the staff that
+// handled it and suggests extra cashiers where the volume exceeds the target load.
+// Consecutive hours needing the same number of extra staff are merged into one window.
+func RecommendStaffing(festivalID uuid.UUID, hours []HourlyStaffing, cfg StaffingConfig, now time.Time) []StaffingRecommendation {
+	if cfg.TargetOrdersPerStaffHour <= 0 {
+		return nil
+	}
+
+	sorted := make([]HourlyStaffing, len(hours))
+	copy(sorted, hours)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].StandName != sorted[j].StandName {
+			return sorted[i].StandName < sorted[j].StandName
+		}
+		if sorted[i].StandID != sorted[j].StandID {
+			return sorted[i].StandID.String() < sorted[j].StandID.String()
+		}
+		return sorted[i].Hour < sorted[j].Hour
+	})
+
+	var recommendations []StaffingRecommendation
+	var current *StaffingRecommendation
+	var orderSum float64
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.AvgOrdersPerHour = orderSum / float64(current.EndHour-current.StartHour)
+		current.Message = fmt.Sprintf("Add %d %s to %s between %s",
+			current.AdditionalStaff, pluralize(current.AdditionalStaff, "cashier", "cashiers"),
+			current.StandName, formatHourRange(current.StartHour, current.EndHour))
+		recommendations = append(recommendations, *current)
+		current = nil
+		orderSum = 0
+	}
+
+	for _, h := range sorted {
+		staff := int(math.Round(h.AvgStaff))
+		if staff == 0 {
+			// Orders taken without a cashier (e.g. self-service) tell nothing about
+			// staffing, so fall back to the stand roster
+			staff = h.RosterStaff
+		}
+		recommended := int(math.Ceil(h.AvgOrders / cfg.TargetOrdersPerStaffHour))
+		additional := recommended - staff
+
+		if current != nil && (current.StandID != h.StandID || current.EndHour != h.Hour || current.AdditionalStaff != additional) {
+			flush()
+		}
+		if additional <= 0 {
+			continue
+		}
+
+		if current == nil {
+			current = &StaffingRecommendation{
+				ID:               uuid.New(),
+				FestivalID:       festivalID,
+				StandID:          h.StandID,
+				StandName:        h.StandName,
+				StartHour:        h.Hour,
+				CurrentStaff:     staff,
+				RecommendedStaff: recommended,
+				AdditionalStaff:  additional,
+				GeneratedAt:      now,
+			}
+		}
+		current.EndHour = h.Hour + 1
+		// Report the staffing of the busiest hour of the window
+		if recommended > current.RecommendedStaff {
+			current.RecommendedStaff = recommended
+			current.CurrentStaff = staff
+		}
+		orderSum += h.AvgOrders
+	}
+	flush()
+
+	return recommendations
+}
+
+// HandleGenerateStaffingRecommendations processes the periodic staffing recommendation task
+func (s *Service) HandleGenerateStaffingRecommendations(ctx context.Context, t *asynq.Task) error {
+	var payload GenerateStaffingPayload
+	if len(t.Payload()) > 0 {
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+
+	festivalIDs := []uuid.UUID{}
+	if payload.FestivalID != nil {
+		festivalIDs = append(festivalIDs, *payload.FestivalID)
+	} else {
+		ids, err := s.repo.GetFestivalsWithOrders(ctx, time.Now().Add(-s.staffing.Lookback))
+		if err != nil {
+			return err
+		}
+		festivalIDs = ids
+	}
+
+	for _, festivalID := range festivalIDs {
+		recommendations, err := s.GenerateStaffingRecommendations(ctx, festivalID)
+		if err != nil {
+			log.Error().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to generate staffing recommendations")
+			continue
+		}
+		log.Info().
+			Str("festival_id", festivalID.String()).
+			Int("recommendations", len(recommendations)).
+			Msg("Generated staffing recommendations")
+	}
+
+	return nil
+}
+
+func formatHourRange(start, end int) string {
+	return fmt.Sprintf("%02d:00-%02d:00", start, end%24)
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
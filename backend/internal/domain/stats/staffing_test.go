@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecommendStaffing_Thresholds(t *testing.T) {
+	festivalID := uuid.New()
+	standID := uuid.New()
+	cfg := DefaultStaffingConfig() // 30 orders per cashier and hour
+
+	tests := []struct {
+		name            string
+		hour            HourlyStaffing
+		wantRecommended int // 0 when no recommendation is expected
+		wantCurrent     int
+	}{
+		{
+			name: "at the target load",
+			hour: HourlyStaffing{AvgOrders: 30, AvgStaff: 1},
+		},
+		{
+			name:            "one order above the target load",
+			hour:            HourlyStaffing{AvgOrders: 31, AvgStaff: 1},
+			wantRecommended: 2,
+			wantCurrent:     1,
+		},
+		{
+			name: "overstaffed",
+			hour: HourlyStaffing{AvgOrders: 45, AvgStaff: 3},
+		},
+		{
+			name:            "staff average rounded down",
+			hour:            HourlyStaffing{AvgOrders: 55, AvgStaff: 1.4},
+			wantRecommended: 2,
+			wantCurrent:     1,
+		},
+		{
+			name: "staff average rounded up",
+			hour: HourlyStaffing{AvgOrders: 55, AvgStaff: 1.6},
+		},
+		{
+			name:            "no cashier falls back to the roster",
+			hour:            HourlyStaffing{AvgOrders: 100, AvgStaff: 0, RosterStaff: 2},
+			wantRecommended: 4,
+			wantCurrent:     2,
+		},
+		{
+			name: "roster covers self-service orders",
+			hour: HourlyStaffing{AvgOrders: 50, AvgStaff: 0, RosterStaff: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.hour
+			h.StandID = standID
+			h.StandName = "Main Bar"
+			h.Hour = 20
+
+			recommendations := RecommendStaffing(festivalID, []HourlyStaffing{h}, cfg, time.Now())
+			if tt.wantRecommended == 0 {
+				assert.Empty(t, recommendations)
+				return
+			}
+			require.Len(t, recommendations, 1)
+			r := recommendations[0]
+			assert.Equal(t, tt.wantRecommended, r.RecommendedStaff)
+			assert.Equal(t, tt.wantCurrent, r.CurrentStaff)
+			assert.Equal(t, tt.wantRecommended-tt.wantCurrent, r.AdditionalStaff)
+			assert.Equal(t, festivalID, r.FestivalID)
+		})
+	}
+
+	t.Run("no target load", func(t *testing.T) {
+		cfg := StaffingConfig{TargetOrdersPerStaffHour: 0}
+		hours := []HourlyStaffing{{StandID: standID, Hour: 20, AvgOrders: 500, AvgStaff: 1}}
+		assert.Nil(t, RecommendStaffing(festivalID, hours, cfg, time.Now()))
+	})
+}
+
+func TestRecommendStaffing_Windows(t *testing.T) {
+	festivalID := uuid.New()
+	bar := uuid.New()
+	food := uuid.New()
+	now := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	hour := func(standID uuid.UUID, name string, h int, orders, staff float64) HourlyStaffing {
+		return HourlyStaffing{StandID: standID, StandName: name, Hour: h, AvgOrders: orders, AvgStaff: staff}
+	}
+	// Listed out of order, as the windows must not depend on it
+	hours := []HourlyStaffing{
+		hour(bar, "Main Bar", 23, 60, 1),  // +1, up to midnight
+		hour(bar, "Main Bar", 19, 90, 2),  // +1, merged with 18:00
+		hour(bar, "Main Bar", 18, 60, 1),  // +1
+		hour(bar, "Main Bar", 20, 120, 1), // +3, splits the window
+		hour(bar, "Main Bar", 21, 30, 1),  // Enough staff
+		hour(food, "Food Court", 12, 65, 1),
+		hour(food, "Food Court", 13, 70, 1),
+	}
+
+	recommendations := RecommendStaffing(festivalID, hours, DefaultStaffingConfig(), now)
+
+	windows := make([]string, 0, len(recommendations))
+	for i := range recommendations {
+		r := recommendations[i].ToResponse()
+		windows = append(windows, r.StandName+" "+r.Window+" "+r.Message)
+	}
+	assert.Equal(t, []string{
+		"Food Court 12:00-14:00 Add 2 cashiers to Food Court between 12:00-14:00",
+		"Main Bar 18:00-20:00 Add 1 cashier to Main Bar between 18:00-20:00",
+		"Main Bar 20:00-21:00 Add 3 cashiers to Main Bar between 20:00-21:00",
+		"Main Bar 23:00-00:00 Add 1 cashier to Main Bar between 23:00-00:00",
+	}, windows)
+
+	evening := recommendations[1]
+	assert.Equal(t, 18, evening.StartHour)
+	assert.Equal(t, 20, evening.EndHour)
+	assert.Equal(t, 75.0, evening.AvgOrdersPerHour)
+	// Staffing of the busiest hour of the window
+	assert.Equal(t, 3, evening.RecommendedStaff)
+	assert.Equal(t, 2, evening.CurrentStaff)
+	assert.Equal(t, now, evening.GeneratedAt)
+
+	assert.Equal(t, 24, recommendations[3].EndHour)
+
+	t.Run("gap between hours", func(t *testing.T) {
+		hours := []HourlyStaffing{
+			hour(bar, "Main Bar", 18, 60, 1),
+			hour(bar, "Main Bar", 20, 60, 1),
+		}
+		recommendations := RecommendStaffing(festivalID, hours, DefaultStaffingConfig(), now)
+		require.Len(t, recommendations, 2)
+		assert.Equal(t, 19, recommendations[0].EndHour)
+		assert.Equal(t, 20, recommendations[1].StartHour)
+	})
+
+	t.Run("same hours at two stands with the same name", func(t *testing.T) {
+		other := uuid.New()
+		hours := []HourlyStaffing{
+			hour(bar, "Bar", 18, 60, 1),
+			hour(other, "Bar", 19, 60, 1),
+		}
+		recommendations := RecommendStaffing(festivalID, hours, DefaultStaffingConfig(), now)
+		assert.Len(t, recommendations, 2)
+	})
+}
+
+// staffingRepository serves the hourly staffing of GenerateStaffingRecommendations
+type staffingRepository struct {
+	Repository
+	hours    []HourlyStaffing
+	since    time.Time
+	replaced []StaffingRecommendation
+}
+
+func (r *staffingRepository) GetHourlyStaffing(ctx context.Context, festivalID uuid.UUID, since time.Time) ([]HourlyStaffing, error) {
+	r.since = since
+	return r.hours, nil
+}
+
+func (r *staffingRepository) ReplaceStaffingRecommendations(ctx context.Context, festivalID uuid.UUID, recommendations []StaffingRecommendation) error {
+	r.replaced = recommendations
+	return nil
+}
+
+func TestService_GenerateStaffingRecommendations(t *testing.T) {
+	repo := &staffingRepository{hours: []HourlyStaffing{
+		{StandID: uuid.New(), StandName: "Main Bar", Hour: 22, AvgOrders: 50, AvgStaff: 1},
+	}}
+	service := NewService(repo, nil)
+	service.SetStaffingConfig(StaffingConfig{Lookback: 7 * 24 * time.Hour, TargetOrdersPerStaffHour: 20})
+
+	recommendations, err := service.GenerateStaffingRecommendations(context.Background(), uuid.New())
+	require.NoError(t, err)
+
+	// History limited to the lookback
+	assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), repo.since, time.Minute)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, 2, recommendations[0].AdditionalStaff)
+	assert.Equal(t, recommendations, repo.replaced)
+}
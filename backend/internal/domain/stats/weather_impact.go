@@ -0,0 +1,182 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HourlyWeatherRevenue is the purchase revenue of one hour with the weather observed during it
+type HourlyWeatherRevenue struct {
+	Hour            time.Time `gorm:"column:hour"`       // Start of the hour, UTC
+	LocalHour       int       `gorm:"column:local_hour"` // Hour of day in the festival timezone
+	Revenue         int64     `gorm:"column:revenue"`
+	Transactions    int       `gorm:"column:transactions"`
+	Condition       *string   `gorm:"column:condition"` // Nil when no observation was stored
+	TemperatureC    *float64  `gorm:"column:temperature_c"`
+	PrecipitationMM *float64  `gorm:"column:precipitation_mm"`
+}
+
+// Wet reports whether it rained, snowed or stormed during the hour
+func (h *HourlyWeatherRevenue) Wet() bool {
+	if h.Condition == nil {
+		return false
+	}
+	switch *h.Condition {
+	case "RAIN", "SNOW", "THUNDERSTORM":
+		return true
+	}
+	return false
+}
+
+// WeatherConditionSummary aggregates revenue over the hours with the same weather condition
+type WeatherConditionSummary struct {
+	Condition            string  `json:"condition"`
+	Hours                int     `json:"hours"`
+	Revenue              int64   `json:"revenue"`
+	RevenueDisplay       string  `json:"revenueDisplay"`
+	AverageHourlyRevenue int64   `json:"averageHourlyRevenue"`
+	AverageTemperatureC  float64 `json:"averageTemperatureC"`
+	AveragePrecipitation float64 `json:"averagePrecipitationMm"`
+}
+
+// WeatherImpact compares revenue in wet and dry hours. Wet hours are compared with dry
+// hours at the same local hour of day so evening peaks do not skew the comparison.
+type WeatherImpact struct {
+	FestivalID             uuid.UUID                 `json:"festivalId"`
+	Timeframe              string                    `json:"timeframe"`
+	ObservedHours          int                       `json:"observedHours"`
+	WetHours               int                       `json:"wetHours"`
+	Revenue                int64                     `json:"revenue"`
+	RevenueDisplay         string                    `json:"revenueDisplay"`
+	WeatherAdjustedRevenue int64                     `json:"weatherAdjustedRevenue"` // Revenue had wet hours sold like dry ones
+	WeatherAdjustedDisplay string                    `json:"weatherAdjustedRevenueDisplay"`
+	WetHourImpactPercent   *float64                  `json:"wetHourImpactPercent,omitempty"` // Nil without comparable dry hours
+	Conditions             []WeatherConditionSummary `json:"conditions"`
+	Hourly                 []WeatherImpactHour       `json:"hourly"`
+	GeneratedAt            time.Time                 `json:"generatedAt"`
+}
+
+// WeatherImpactHour is one point of the revenue/weather series
+type WeatherImpactHour struct {
+	Hour            string   `json:"hour"`
+	Revenue         int64    `json:"revenue"`
+	ExpectedRevenue *int64   `json:"expectedRevenue,omitempty"` // Dry-hour baseline, set for wet hours
+	Condition       string   `json:"condition,omitempty"`
+	TemperatureC    *float64 `json:"temperatureC,omitempty"`
+	PrecipitationMM *float64 `json:"precipitationMm,omitempty"`
+}
+
+// ComputeWeatherImpact builds the weather impact from hourly revenue joined with observations.
+// Hours without an observation count towards revenue but not towards any comparison.
+func ComputeWeatherImpact(festivalID uuid.UUID, timeframe Timeframe, hours []HourlyWeatherRevenue) *WeatherImpact {
+	impact := &WeatherImpact{
+		FestivalID:  festivalID,
+		Timeframe:   string(timeframe),
+		Conditions:  []WeatherConditionSummary{},
+		Hourly:      make([]WeatherImpactHour, 0, len(hours)),
+		GeneratedAt: time.Now(),
+	}
+
+	// Dry-hour baseline per local hour of day, and overall as a fallback
+	var drySum [24]int64
+	var dryCount [24]int
+	var dryTotal int64
+	var dryHours int
+	for _, h := range hours {
+		if h.Condition != nil && !h.Wet() {
+			drySum[h.LocalHour%24] += h.Revenue
+			dryCount[h.LocalHour%24]++
+			dryTotal += h.Revenue
+			dryHours++
+		}
+	}
+
+	type conditionTotals struct {
+		hours         int
+		revenue       int64
+		temperature   float64
+		temperatures  int
+		precipitation float64
+	}
+	totals := map[string]*conditionTotals{}
+
+	var wetActual, wetExpected int64
+	for _, h := range hours {
+		impact.Revenue += h.Revenue
+		point := WeatherImpactHour{
+			Hour:            h.Hour.UTC().Format(time.RFC3339),
+			Revenue:         h.Revenue,
+			TemperatureC:    h.TemperatureC,
+			PrecipitationMM: h.PrecipitationMM,
+		}
+
+		if h.Condition != nil {
+			impact.ObservedHours++
+			point.Condition = *h.Condition
+
+			t, ok := totals[*h.Condition]
+			if !ok {
+				t = &conditionTotals{}
+				totals[*h.Condition] = t
+			}
+			t.hours++
+			t.revenue += h.Revenue
+			if h.TemperatureC != nil {
+				t.temperature += *h.TemperatureC
+				t.temperatures++
+			}
+			if h.PrecipitationMM != nil {
+				t.precipitation += *h.PrecipitationMM
+			}
+
+			if h.Wet() {
+				impact.WetHours++
+				var expected int64
+				switch {
+				case dryCount[h.LocalHour%24] > 0:
+					expected = drySum[h.LocalHour%24] / int64(dryCount[h.LocalHour%24])
+				case dryHours > 0:
+					expected = dryTotal / int64(dryHours)
+				default:
+					expected = h.Revenue
+				}
+				point.ExpectedRevenue = &expected
+				wetActual += h.Revenue
+				wetExpected += expected
+			}
+		}
+
+		impact.Hourly = append(impact.Hourly, point)
+	}
+
+	impact.WeatherAdjustedRevenue = impact.Revenue - wetActual + wetExpected
+	impact.RevenueDisplay = formatCurrency(impact.Revenue)
+	impact.WeatherAdjustedDisplay = formatCurrency(impact.WeatherAdjustedRevenue)
+	if dryHours > 0 && wetExpected > 0 {
+		percent := math.Round(float64(wetActual-wetExpected)/float64(wetExpected)*1000) / 10
+		impact.WetHourImpactPercent = &percent
+	}
+
+	for condition, t := range totals {
+		summary := WeatherConditionSummary{
+			Condition:            condition,
+			Hours:                t.hours,
+			Revenue:              t.revenue,
+			RevenueDisplay:       formatCurrency(t.revenue),
+			AverageHourlyRevenue: t.revenue / int64(t.hours),
+			AveragePrecipitation: math.Round(t.precipitation/float64(t.hours)*10) / 10,
+		}
+		if t.temperatures > 0 {
+			summary.AverageTemperatureC = math.Round(t.temperature/float64(t.temperatures)*10) / 10
+		}
+		impact.Conditions = append(impact.Conditions, summary)
+	}
+	sort.Slice(impact.Conditions, func(i, j int) bool {
+		return impact.Conditions[i].Hours > impact.Conditions[j].Hours
+	})
+
+	return impact
+}
@@ -0,0 +1,288 @@
+package statuspage
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin incident routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	incidents := r.Group("/status/incidents")
+	{
+		incidents.GET("", h.ListIncidents)
+		incidents.POST("", h.CreateIncident)
+		incidents.GET("/components", h.Components)
+		incidents.GET("/:id", h.GetIncident)
+		incidents.PATCH("/:id", h.UpdateIncident)
+		incidents.POST("/:id/updates", h.PostUpdate)
+		incidents.DELETE("/:id", h.DeleteIncident)
+	}
+}
+
+// RegisterPublicRoutes registers the unauthenticated status feed polled by the
+// monitoring of organizers. The limit handlers rate limit the callers.
+func (h *Handler) RegisterPublicRoutes(r *gin.RouterGroup, limit ...gin.HandlerFunc) {
+	status := r.Group("/status")
+	status.Use(limit...)
+	{
+		status.GET("", h.GetFeed)
+		status.GET("/incidents", h.GetHistory)
+	}
+}
+
+// GetFeed returns the status of the platform
+// @Summary Get platform status
+// @Description Get the state of each component of the platform, from its health checks and the unresolved incidents posted by the platform team (no auth required). Refreshed every 30 seconds; rate limited per IP address.
+// @Tags status
+// @Produce json
+// @Success 200 {object} response.Response{data=Feed} "Platform status"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Router /status [get]
+func (h *Handler) GetFeed(c *gin.Context) {
+	feed, err := h.service.GetFeed(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=30")
+	response.OK(c, feed)
+}
+
+// GetHistory returns the recent incidents
+// @Summary Get incident history
+// @Description Get the incidents of the last 30 days with their updates, the latest first (no auth required). Rate limited per IP address.
+// @Tags status
+// @Produce json
+// @Success 200 {object} response.Response{data=[]Incident} "Incidents"
+// @Failure 429 {object} response.ErrorResponse "Too many requests"
+// @Router /status/incidents [get]
+func (h *Handler) GetHistory(c *gin.Context) {
+	incidents, err := h.service.GetHistory(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	response.OK(c, incidents)
+}
+
+// ListIncidents lists the incidents
+// @Summary List incidents
+// @Description Get the incidents posted on the status feed, the latest started first
+// @Tags status
+// @Produce json
+// @Param unresolved query bool false "Only the incidents not resolved yet"
+// @Success 200 {object} response.Response{data=[]Incident} "Incidents"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/status/incidents [get]
+func (h *Handler) ListIncidents(c *gin.Context) {
+	filter := IncidentFilter{Unresolved: c.Query("unresolved") == "true"}
+	incidents, err := h.service.ListIncidents(c.Request.Context(), filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, incidents)
+}
+
+// CreateIncident posts an incident
+// @Summary Create incident
+// @Description Post an incident on the public status feed with its first update. Its impact sets the state of the affected components until it is resolved: MINOR degrades them, MAJOR is a partial outage and CRITICAL a major outage.
+// @Tags status
+// @Accept json
+// @Produce json
+// @Param request body CreateIncidentRequest true "Incident"
+// @Success 201 {object} response.Response{data=Incident} "Incident created"
+// @Failure 400 {object} response.ErrorResponse "Invalid incident"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/status/incidents [post]
+func (h *Handler) CreateIncident(c *gin.Context) {
+	var req CreateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	incident, err := h.service.CreateIncident(c.Request.Context(), req, adminID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, incident)
+}
+
+// Components lists the components incidents can affect
+// @Summary List status components
+// @Description Get the keys of the components incidents can affect: the API and the components of the health checks
+// @Tags status
+// @Produce json
+// @Success 200 {object} response.Response{data=[]string} "Component keys"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/status/incidents/components [get]
+func (h *Handler) Components(c *gin.Context) {
+	response.OK(c, h.service.Components(c.Request.Context()))
+}
+
+// GetIncident gets an incident
+// @Summary Get incident
+// @Description Get an incident with its updates, the latest first
+// @Tags status
+// @Produce json
+// @Param id path string true "Incident ID" format(uuid)
+// @Success 200 {object} response.Response{data=Incident} "Incident"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Incident not found"
+// @Security BearerAuth
+// @Router /admin/status/incidents/{id} [get]
+func (h *Handler) GetIncident(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid incident ID", nil)
+		return
+	}
+
+	incident, err := h.service.GetIncident(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, incident)
+}
+
+// UpdateIncident corrects an incident
+// @Summary Update incident
+// @Description Correct the title, impact or affected components of an incident. Progress is posted as updates.
+// @Tags status
+// @Accept json
+// @Produce json
+// @Param id path string true "Incident ID" format(uuid)
+// @Param request body UpdateIncidentRequest true "Changes"
+// @Success 200 {object} response.Response{data=Incident} "Incident updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid incident"
+// @Failure 404 {object} response.ErrorResponse "Incident not found"
+// @Security BearerAuth
+// @Router /admin/status/incidents/{id} [patch]
+func (h *Handler) UpdateIncident(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid incident ID", nil)
+		return
+	}
+
+	var req UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	incident, err := h.service.UpdateIncident(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, incident)
+}
+
+// PostUpdate posts the progress of an incident
+// @Summary Post incident update
+// @Description Post the progress of an unresolved incident. A RESOLVED update resolves it and gives its components back the state of their health checks.
+// @Tags status
+// @Accept json
+// @Produce json
+// @Param id path string true "Incident ID" format(uuid)
+// @Param request body PostUpdateRequest true "Update"
+// @Success 201 {object} response.Response{data=Incident} "Update posted"
+// @Failure 400 {object} response.ErrorResponse "Invalid update"
+// @Failure 404 {object} response.ErrorResponse "Incident not found"
+// @Failure 409 {object} response.ErrorResponse "Incident already resolved"
+// @Security BearerAuth
+// @Router /admin/status/incidents/{id}/updates [post]
+func (h *Handler) PostUpdate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid incident ID", nil)
+		return
+	}
+
+	var req PostUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	incident, err := h.service.PostUpdate(c.Request.Context(), id, req, adminID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, incident)
+}
+
+// DeleteIncident removes an incident
+// @Summary Delete incident
+// @Description Remove an incident posted by mistake with its updates. Incidents that happened should be resolved instead.
+// @Tags status
+// @Param id path string true "Incident ID" format(uuid)
+// @Success 204 "Incident deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Incident not found"
+// @Security BearerAuth
+// @Router /admin/status/incidents/{id} [delete]
+func (h *Handler) DeleteIncident(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid incident ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteIncident(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func adminID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrIncidentNotFound):
+		response.NotFound(c, "Incident not found")
+	case errors.Is(err, ErrUnknownComponent):
+		response.BadRequest(c, "UNKNOWN_COMPONENT", err.Error(), nil)
+	case errors.Is(err, ErrIncidentResolved):
+		response.Conflict(c, "INCIDENT_RESOLVED", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
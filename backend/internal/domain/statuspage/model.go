@@ -0,0 +1,161 @@
+package statuspage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Status page errors
+var (
+	ErrIncidentNotFound = errors.New("incident not found")
+	ErrUnknownComponent = errors.New("unknown status component")
+	ErrIncidentResolved = errors.New("incident is resolved")
+)
+
+// ComponentStatus is the state of a part of the platform shown on the status feed
+type ComponentStatus string
+
+const (
+	ComponentOperational   ComponentStatus = "OPERATIONAL"
+	ComponentDegraded      ComponentStatus = "DEGRADED"
+	ComponentPartialOutage ComponentStatus = "PARTIAL_OUTAGE"
+	ComponentMajorOutage   ComponentStatus = "MAJOR_OUTAGE"
+)
+
+// severity orders the component states from operational to major outage
+func (s ComponentStatus) severity() int {
+	switch s {
+	case ComponentDegraded:
+		return 1
+	case ComponentPartialOutage:
+		return 2
+	case ComponentMajorOutage:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// worst returns the more severe of two component states
+func worst(a, b ComponentStatus) ComponentStatus {
+	if b.severity() > a.severity() {
+		return b
+	}
+	return a
+}
+
+// IncidentStatus is the progress of an incident, as posted by platform admins
+type IncidentStatus string
+
+const (
+	IncidentInvestigating IncidentStatus = "INVESTIGATING"
+	IncidentIdentified    IncidentStatus = "IDENTIFIED"
+	IncidentMonitoring    IncidentStatus = "MONITORING" // Fixed, watching that it holds
+	IncidentResolved      IncidentStatus = "RESOLVED"
+)
+
+// IncidentImpact is how badly an incident affects its components
+type IncidentImpact string
+
+const (
+	ImpactNone     IncidentImpact = "NONE"     // Informational, components stay operational
+	ImpactMinor    IncidentImpact = "MINOR"    // Components degraded
+	ImpactMajor    IncidentImpact = "MAJOR"    // Components partially down
+	ImpactCritical IncidentImpact = "CRITICAL" // Components down
+)
+
+// componentStatus is the state an incident of this impact gives its components
+func (i IncidentImpact) componentStatus() ComponentStatus {
+	switch i {
+	case ImpactMinor:
+		return ComponentDegraded
+	case ImpactMajor:
+		return ComponentPartialOutage
+	case ImpactCritical:
+		return ComponentMajorOutage
+	default:
+		return ComponentOperational
+	}
+}
+
+// Incident is an outage or a disruption of the platform posted by platform admins
+type Incident struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Title      string           `json:"title" gorm:"not null"`
+	Status     IncidentStatus   `json:"status" gorm:"not null"`
+	Impact     IncidentImpact   `json:"impact" gorm:"not null"`
+	Components pq.StringArray   `json:"components" gorm:"type:text[];not null"` // Keys of the affected components
+	StartedAt  time.Time        `json:"startedAt" gorm:"not null"`
+	ResolvedAt *time.Time       `json:"resolvedAt,omitempty"`
+	CreatedBy  *uuid.UUID       `json:"-" gorm:"type:uuid"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	UpdatedAt  time.Time        `json:"updatedAt"`
+	Updates    []IncidentUpdate `json:"updates" gorm:"foreignKey:IncidentID"` // Latest first
+}
+
+func (Incident) TableName() string {
+	return "status_incidents"
+}
+
+// IncidentUpdate is a message posted on an incident as it progresses
+type IncidentUpdate struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IncidentID uuid.UUID      `json:"-" gorm:"type:uuid;not null;index"`
+	Status     IncidentStatus `json:"status" gorm:"not null"`
+	Message    string         `json:"message" gorm:"not null"`
+	PostedBy   *uuid.UUID     `json:"-" gorm:"type:uuid"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
+
+func (IncidentUpdate) TableName() string {
+	return "status_incident_updates"
+}
+
+// Component is a part of the platform on the status feed
+type Component struct {
+	Key    string          `json:"key"`
+	Name   string          `json:"name"`
+	Status ComponentStatus `json:"status"`
+}
+
+// Feed is the machine-readable status of the platform, polled by the monitoring of
+// organizers
+type Feed struct {
+	Status      ComponentStatus `json:"status"` // Worst state of the components
+	Components  []Component     `json:"components"`
+	Incidents   []Incident      `json:"incidents"` // Unresolved incidents, latest first
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// IncidentFilter narrows the listed incidents
+type IncidentFilter struct {
+	Unresolved bool       // Only the incidents not resolved yet
+	Since      *time.Time // Only the incidents started since then
+	Limit      int
+}
+
+// CreateIncidentRequest posts a new incident with its first update
+type CreateIncidentRequest struct {
+	Title      string         `json:"title" binding:"required,max=200"`
+	Status     IncidentStatus `json:"status" binding:"omitempty,oneof=INVESTIGATING IDENTIFIED MONITORING"`
+	Impact     IncidentImpact `json:"impact" binding:"required,oneof=NONE MINOR MAJOR CRITICAL"`
+	Components []string       `json:"components" binding:"required,min=1,max=20"`
+	Message    string         `json:"message" binding:"required,max=2000"`
+	StartedAt  *time.Time     `json:"startedAt,omitempty"` // Now when not given
+}
+
+// UpdateIncidentRequest corrects the title, impact or components of an incident
+type UpdateIncidentRequest struct {
+	Title      *string         `json:"title,omitempty" binding:"omitempty,max=200"`
+	Impact     *IncidentImpact `json:"impact,omitempty" binding:"omitempty,oneof=NONE MINOR MAJOR CRITICAL"`
+	Components *[]string       `json:"components,omitempty" binding:"omitempty,min=1,max=20"`
+}
+
+// PostUpdateRequest posts the progress of an incident; RESOLVED resolves it
+type PostUpdateRequest struct {
+	Status  IncidentStatus `json:"status" binding:"required,oneof=INVESTIGATING IDENTIFIED MONITORING RESOLVED"`
+	Message string         `json:"message" binding:"required,max=2000"`
+}
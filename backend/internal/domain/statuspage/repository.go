@@ -0,0 +1,104 @@
+package statuspage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateIncident(ctx context.Context, incident *Incident) error
+	UpdateIncident(ctx context.Context, incident *Incident) error
+	AddUpdate(ctx context.Context, incident *Incident, update *IncidentUpdate) error
+	DeleteIncident(ctx context.Context, id uuid.UUID) error
+	GetIncident(ctx context.Context, id uuid.UUID) (*Incident, error)
+	ListIncidents(ctx context.Context, filter IncidentFilter) ([]Incident, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// CreateIncident records an incident with its first update
+func (r *repository) CreateIncident(ctx context.Context, incident *Incident) error {
+	if err := r.db.WithContext(ctx).Create(incident).Error; err != nil {
+		return fmt.Errorf("failed to create incident: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateIncident(ctx context.Context, incident *Incident) error {
+	if err := r.db.WithContext(ctx).Omit("Updates").Save(incident).Error; err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+	return nil
+}
+
+// AddUpdate records an update of an incident and the status it gives the incident
+func (r *repository) AddUpdate(ctx context.Context, incident *Incident, update *IncidentUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(update).Error; err != nil {
+			return fmt.Errorf("failed to add incident update: %w", err)
+		}
+		if err := tx.Omit("Updates").Save(incident).Error; err != nil {
+			return fmt.Errorf("failed to update incident: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteIncident removes an incident posted by mistake with its updates
+func (r *repository) DeleteIncident(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("incident_id = ?", id).Delete(&IncidentUpdate{}).Error; err != nil {
+			return fmt.Errorf("failed to delete incident updates: %w", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(&Incident{}).Error; err != nil {
+			return fmt.Errorf("failed to delete incident: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetIncident(ctx context.Context, id uuid.UUID) (*Incident, error) {
+	var incident Incident
+	err := r.db.WithContext(ctx).Preload("Updates", latestUpdatesFirst).Where("id = ?", id).First(&incident).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+	return &incident, nil
+}
+
+// ListIncidents lists incidents with their updates, the latest started first
+func (r *repository) ListIncidents(ctx context.Context, filter IncidentFilter) ([]Incident, error) {
+	query := r.db.WithContext(ctx).Preload("Updates", latestUpdatesFirst)
+	if filter.Unresolved {
+		query = query.Where("status <> ?", IncidentResolved)
+	}
+	if filter.Since != nil {
+		query = query.Where("started_at >= ?", *filter.Since)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var incidents []Incident
+	if err := query.Order("started_at DESC").Find(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %w", err)
+	}
+	return incidents, nil
+}
+
+func latestUpdatesFirst(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at DESC")
+}
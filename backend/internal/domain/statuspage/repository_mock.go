@@ -0,0 +1,53 @@
+package statuspage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateIncident(ctx context.Context, incident *Incident) error {
+	args := m.Called(ctx, incident)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateIncident(ctx context.Context, incident *Incident) error {
+	args := m.Called(ctx, incident)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AddUpdate(ctx context.Context, incident *Incident, update *IncidentUpdate) error {
+	args := m.Called(ctx, incident, update)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteIncident(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetIncident(ctx context.Context, id uuid.UUID) (*Incident, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Incident), args.Error(1)
+}
+
+func (m *MockRepository) ListIncidents(ctx context.Context, filter IncidentFilter) ([]Incident, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Incident), args.Error(1)
+}
@@ -0,0 +1,324 @@
+package statuspage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+)
+
+// feedTTL is how long the feed is served from memory, so that the health checks run at
+// most this often whatever the number of monitors polling the feed
+const feedTTL = 30 * time.Second
+
+// historyDays is how far back the public incident history goes
+const historyDays = 30
+
+// APIComponent is the platform as a whole, as reported by the overall health check
+const APIComponent = "api"
+
+// componentNames are the public names of the components; the other health checks show
+// under their own name
+var componentNames = map[string]string{
+	APIComponent:  "API",
+	"database":    "Database",
+	"redis":       "Cache and realtime updates",
+	"replication": "Standby region replication",
+}
+
+// HealthSource checks the components of the platform; satisfied by
+// monitoring.HealthChecker
+type HealthSource interface {
+	Check(ctx context.Context) monitoring.HealthReport
+}
+
+// Service publishes the status of the platform from its health checks and the incidents
+// posted by platform admins
+type Service struct {
+	repo   Repository
+	health HealthSource
+	now    func() time.Time
+
+	mu       sync.Mutex
+	feed     *Feed
+	cachedAt time.Time
+}
+
+// NewService creates the status page service
+func NewService(repo Repository, health HealthSource) *Service {
+	return &Service{
+		repo:   repo,
+		health: health,
+		now:    time.Now,
+	}
+}
+
+// GetFeed returns the status of the platform, computed at most every feedTTL
+func (s *Service) GetFeed(ctx context.Context) (*Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.feed != nil && now.Sub(s.cachedAt) < feedTTL {
+		return s.feed, nil
+	}
+
+	incidents, err := s.repo.ListIncidents(ctx, IncidentFilter{Unresolved: true})
+	if err != nil {
+		return nil, err
+	}
+	s.feed = BuildFeed(s.health.Check(ctx), incidents, now)
+	s.cachedAt = now
+	return s.feed, nil
+}
+
+// GetHistory returns the incidents of the last 30 days, the latest first
+func (s *Service) GetHistory(ctx context.Context) ([]Incident, error) {
+	since := s.now().AddDate(0, 0, -historyDays)
+	return s.repo.ListIncidents(ctx, IncidentFilter{Since: &since, Limit: 100})
+}
+
+// BuildFeed derives the state of each component from its health check, made worse by
+// the unresolved incidents affecting it
+func BuildFeed(report monitoring.HealthReport, incidents []Incident, now time.Time) *Feed {
+	statuses := map[string]ComponentStatus{APIComponent: healthStatus(report.Status)}
+	for _, component := range report.Components {
+		statuses[component.Name] = healthStatus(component.Status)
+	}
+	for _, incident := range incidents {
+		if incident.Status == IncidentResolved {
+			continue
+		}
+		for _, key := range incident.Components {
+			statuses[key] = worst(statuses[key], incident.Impact.componentStatus())
+		}
+	}
+
+	feed := &Feed{
+		Status:      ComponentOperational,
+		Components:  make([]Component, 0, len(statuses)),
+		Incidents:   make([]Incident, 0, len(incidents)),
+		GeneratedAt: now,
+	}
+	for key, status := range statuses {
+		if status == "" {
+			status = ComponentOperational
+		}
+		feed.Components = append(feed.Components, Component{Key: key, Name: componentName(key), Status: status})
+		feed.Status = worst(feed.Status, status)
+	}
+	sort.Slice(feed.Components, func(i, j int) bool {
+		a, b := feed.Components[i].Key, feed.Components[j].Key
+		if a == APIComponent || b == APIComponent {
+			return a == APIComponent
+		}
+		return a < b
+	})
+	for _, incident := range incidents {
+		if incident.Status != IncidentResolved {
+			feed.Incidents = append(feed.Incidents, incident)
+		}
+	}
+	return feed
+}
+
+// CreateIncident posts an incident on the status feed with its first update
+func (s *Service) CreateIncident(ctx context.Context, req CreateIncidentRequest, adminID *uuid.UUID) (*Incident, error) {
+	components, err := s.checkComponents(ctx, req.Components)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	status := req.Status
+	if status == "" {
+		status = IncidentInvestigating
+	}
+	startedAt := now
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
+	}
+
+	incident := &Incident{
+		ID:         uuid.New(),
+		Title:      strings.TrimSpace(req.Title),
+		Status:     status,
+		Impact:     req.Impact,
+		Components: components,
+		StartedAt:  startedAt,
+		CreatedBy:  adminID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	incident.Updates = []IncidentUpdate{{
+		ID:         uuid.New(),
+		IncidentID: incident.ID,
+		Status:     status,
+		Message:    strings.TrimSpace(req.Message),
+		PostedBy:   adminID,
+		CreatedAt:  now,
+	}}
+	if err := s.repo.CreateIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	return incident, nil
+}
+
+// UpdateIncident corrects the title, impact or components of an incident
+func (s *Service) UpdateIncident(ctx context.Context, id uuid.UUID, req UpdateIncidentRequest) (*Incident, error) {
+	incident, err := s.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		incident.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Impact != nil {
+		incident.Impact = *req.Impact
+	}
+	if req.Components != nil {
+		if incident.Components, err = s.checkComponents(ctx, *req.Components); err != nil {
+			return nil, err
+		}
+	}
+	incident.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	return incident, nil
+}
+
+// PostUpdate posts the progress of an unresolved incident. A RESOLVED update resolves
+// it and gives its components back their health check state.
+func (s *Service) PostUpdate(ctx context.Context, id uuid.UUID, req PostUpdateRequest, adminID *uuid.UUID) (*Incident, error) {
+	incident, err := s.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Status == IncidentResolved {
+		return nil, ErrIncidentResolved
+	}
+
+	now := s.now()
+	update := IncidentUpdate{
+		ID:         uuid.New(),
+		IncidentID: incident.ID,
+		Status:     req.Status,
+		Message:    strings.TrimSpace(req.Message),
+		PostedBy:   adminID,
+		CreatedAt:  now,
+	}
+	incident.Status = req.Status
+	if req.Status == IncidentResolved {
+		incident.ResolvedAt = &now
+	}
+	incident.UpdatedAt = now
+
+	if err := s.repo.AddUpdate(ctx, incident, &update); err != nil {
+		return nil, err
+	}
+	incident.Updates = append([]IncidentUpdate{update}, incident.Updates...)
+
+	s.invalidate()
+	return incident, nil
+}
+
+// DeleteIncident removes an incident posted by mistake
+func (s *Service) DeleteIncident(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetIncident(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteIncident(ctx, id); err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// GetIncident returns an incident with its updates
+func (s *Service) GetIncident(ctx context.Context, id uuid.UUID) (*Incident, error) {
+	incident, err := s.repo.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident == nil {
+		return nil, ErrIncidentNotFound
+	}
+	return incident, nil
+}
+
+// ListIncidents lists incidents, the latest started first
+func (s *Service) ListIncidents(ctx context.Context, filter IncidentFilter) ([]Incident, error) {
+	return s.repo.ListIncidents(ctx, filter)
+}
+
+// Components returns the keys incidents can affect: the API and the components of the
+// health checks
+func (s *Service) Components(ctx context.Context) []string {
+	report := s.health.Check(ctx)
+	keys := []string{APIComponent}
+	for _, component := range report.Components {
+		keys = append(keys, component.Name)
+	}
+	sort.Strings(keys[1:])
+	return keys
+}
+
+// checkComponents checks that incidents only affect known components, without
+// duplicates
+func (s *Service) checkComponents(ctx context.Context, keys []string) ([]string, error) {
+	known := make(map[string]bool)
+	for _, key := range s.Components(ctx) {
+		known[key] = true
+	}
+
+	components := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !known[key] {
+			return nil, ErrUnknownComponent
+		}
+		if !seen[key] {
+			seen[key] = true
+			components = append(components, key)
+		}
+	}
+	return components, nil
+}
+
+// invalidate drops the cached feed, so that incident changes show at once
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.feed = nil
+	s.mu.Unlock()
+}
+
+func healthStatus(status monitoring.HealthStatus) ComponentStatus {
+	switch status {
+	case monitoring.StatusDegraded:
+		return ComponentDegraded
+	case monitoring.StatusUnhealthy:
+		return ComponentMajorOutage
+	default:
+		return ComponentOperational
+	}
+}
+
+func componentName(key string) string {
+	if name, ok := componentNames[key]; ok {
+		return name
+	}
+	return key
+}
@@ -0,0 +1,122 @@
+package statuspage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHealth struct {
+	report monitoring.HealthReport
+}
+
+func (h *fakeHealth) Check(ctx context.Context) monitoring.HealthReport {
+	return h.report
+}
+
+var testNow = time.Date(2026, 7, 18, 14, 0, 0, 0, time.UTC)
+
+func newTestService(report monitoring.HealthReport) (*Service, *MockRepository) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, &fakeHealth{report: report})
+	service.now = func() time.Time { return testNow }
+	return service, mockRepo
+}
+
+func healthyReport() monitoring.HealthReport {
+	return monitoring.HealthReport{
+		Status: monitoring.StatusHealthy,
+		Components: []monitoring.ComponentHealth{
+			{Name: "redis", Status: monitoring.StatusHealthy},
+			{Name: "database", Status: monitoring.StatusHealthy},
+		},
+	}
+}
+
+func TestBuildFeed(t *testing.T) {
+	report := healthyReport()
+	report.Status = monitoring.StatusDegraded
+	report.Components[0].Status = monitoring.StatusDegraded
+
+	feed := BuildFeed(report, []Incident{
+		{Title: "Slow payments", Status: IncidentIdentified, Impact: ImpactMajor, Components: []string{"database"}},
+		{Title: "Cache restart", Status: IncidentInvestigating, Impact: ImpactMinor, Components: []string{"redis"}},
+		{Title: "Old outage", Status: IncidentResolved, Impact: ImpactCritical, Components: []string{"api"}},
+	}, testNow)
+
+	assert.Equal(t, []Component{
+		{Key: "api", Name: "API", Status: ComponentDegraded},
+		{Key: "database", Name: "Database", Status: ComponentPartialOutage},
+		{Key: "redis", Name: "Cache and realtime updates", Status: ComponentDegraded},
+	}, feed.Components, "the API first, incidents only make components worse")
+	assert.Equal(t, ComponentPartialOutage, feed.Status)
+	assert.Len(t, feed.Incidents, 2, "resolved incidents are left out")
+
+	feed = BuildFeed(healthyReport(), nil, testNow)
+	assert.Equal(t, ComponentOperational, feed.Status)
+	assert.Empty(t, feed.Incidents)
+}
+
+func TestService_Incidents(t *testing.T) {
+	service, mockRepo := newTestService(healthyReport())
+	ctx := context.Background()
+	unresolved := IncidentFilter{Unresolved: true}
+
+	_, err := service.CreateIncident(ctx, CreateIncidentRequest{
+		Title: "Payments", Impact: ImpactMajor, Components: []string{"payments"}, Message: "Looking into it",
+	}, nil)
+	assert.ErrorIs(t, err, ErrUnknownComponent)
+	mockRepo.AssertNotCalled(t, "CreateIncident", mock.Anything, mock.Anything)
+
+	stored := &Incident{}
+	mockRepo.On("CreateIncident", mock.Anything, mock.AnythingOfType("*statuspage.Incident")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Incident) }).
+		Return(nil).Once()
+	incident, err := service.CreateIncident(ctx, CreateIncidentRequest{
+		Title: " Slow database ", Impact: ImpactMajor, Components: []string{"Database", "database"}, Message: "Looking into it",
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Slow database", incident.Title)
+	assert.Equal(t, IncidentInvestigating, incident.Status)
+	assert.Equal(t, testNow, incident.StartedAt)
+	assert.Equal(t, []string{"database"}, []string(incident.Components))
+	require.Len(t, incident.Updates, 1)
+
+	mockRepo.On("ListIncidents", mock.Anything, unresolved).Return([]Incident{*stored}, nil).Once()
+	feed, err := service.GetFeed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ComponentPartialOutage, feed.Status)
+
+	_, err = service.GetFeed(ctx)
+	require.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "ListIncidents", 1)
+
+	mockRepo.On("GetIncident", mock.Anything, stored.ID).Return(stored, nil).Times(3)
+	mockRepo.On("AddUpdate", mock.Anything, stored, mock.MatchedBy(func(u *IncidentUpdate) bool {
+		return u.IncidentID == stored.ID && u.Status == IncidentResolved
+	})).Return(nil).Once()
+	incident, err = service.PostUpdate(ctx, incident.ID, PostUpdateRequest{Status: IncidentResolved, Message: "Fixed"}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, incident.ResolvedAt)
+	assert.Len(t, incident.Updates, 2)
+	assert.Equal(t, IncidentResolved, incident.Updates[0].Status, "latest update first")
+
+	mockRepo.On("ListIncidents", mock.Anything, unresolved).Return([]Incident{}, nil).Once()
+	feed, err = service.GetFeed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ComponentOperational, feed.Status, "incident changes show at once")
+
+	_, err = service.PostUpdate(ctx, incident.ID, PostUpdateRequest{Status: IncidentMonitoring, Message: "Again"}, nil)
+	assert.ErrorIs(t, err, ErrIncidentResolved)
+
+	mockRepo.On("DeleteIncident", mock.Anything, stored.ID).Return(nil).Once()
+	require.NoError(t, service.DeleteIncident(ctx, incident.ID))
+	mockRepo.On("GetIncident", mock.Anything, stored.ID).Return(nil, nil).Once()
+	assert.ErrorIs(t, service.DeleteIncident(ctx, incident.ID), ErrIncidentNotFound)
+	mockRepo.AssertExpectations(t)
+}
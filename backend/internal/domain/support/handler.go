@@ -83,7 +83,7 @@ func (h *Handler) CreateTicket(c *gin.Context) {
 
 	var req CreateTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -273,7 +273,7 @@ func (h *Handler) AddMessage(c *gin.Context) {
 
 	var req CreateMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -325,7 +325,7 @@ func (h *Handler) UpdateTicketStatus(c *gin.Context) {
 
 	var req UpdateTicketStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -371,7 +371,7 @@ func (h *Handler) AssignTicket(c *gin.Context) {
 
 	var req AssignTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -548,7 +548,7 @@ func (h *Handler) CreateFAQ(c *gin.Context) {
 
 	var req CreateFAQRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -619,7 +619,7 @@ func (h *Handler) UpdateFAQ(c *gin.Context) {
 
 	var req UpdateFAQRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -671,6 +671,75 @@ func (h *Handler) DeleteFAQ(c *gin.Context) {
 
 // Helper functions
 
+// RegisterSearchRoutes registers the festival-scoped search of orders and transactions,
+// which should be restricted to support staff
+func (h *Handler) RegisterSearchRoutes(r *gin.RouterGroup) {
+	r.GET("/support/search", h.Search)
+}
+
+// Search finds orders and transactions for support staff
+// @Summary Search orders and transactions
+// @Description Find the orders and wallet transactions an attendee asks about, e.g. "the 23.50€ order around 21:10 at Bar 2". A time window of at most 24 hours and at least one other filter are required. Every search is recorded in the audit log.
+// @Tags support
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param amount query int false "Exact amount, in cents"
+// @Param amountMin query int false "Minimum amount, in cents"
+// @Param amountMax query int false "Maximum amount, in cents"
+// @Param at query string false "Around this time (RFC3339), closest first" format(date-time)
+// @Param windowMinutes query int false "Minutes before and after at" default(15)
+// @Param from query string false "Start of the time window (RFC3339)" format(date-time)
+// @Param to query string false "End of the time window (RFC3339)" format(date-time)
+// @Param standId query string false "Stand" format(uuid)
+// @Param staffId query string false "Staff member who processed it" format(uuid)
+// @Param cardLast4 query string false "Last 4 digits of a card the wallet was topped up with"
+// @Param kind query string false "Only orders or only transactions" Enums(orders, transactions)
+// @Param limit query int false "Maximum orders and transactions each" default(50)
+// @Success 200 {object} response.Response{data=SearchResult} "Matches"
+// @Failure 400 {object} response.ErrorResponse "Invalid search"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden - support staff only"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/support/search [get]
+func (h *Handler) Search(c *gin.Context) {
+	festivalID, err := getFestivalID(c)
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL", "Festival context required", nil)
+		return
+	}
+
+	var query SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	var staffID *uuid.UUID
+	if userID, err := getUserID(c); err == nil {
+		staffID = &userID
+	}
+
+	result, err := h.service.Search(c.Request.Context(), festivalID, query, staffID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSearchTooBroad):
+			response.BadRequest(c, "SEARCH_TOO_BROAD", err.Error(), nil)
+		case errors.Is(err, ErrSearchWindowRequired), errors.Is(err, ErrSearchWindowConflict),
+			errors.Is(err, ErrSearchWindowInvalid), errors.Is(err, ErrSearchWindowTooWide):
+			response.BadRequest(c, "INVALID_TIME_WINDOW", err.Error(), nil)
+		case errors.Is(err, ErrSearchAmountConflict), errors.Is(err, ErrSearchAmountInvalid):
+			response.BadRequest(c, "INVALID_AMOUNT", err.Error(), nil)
+		case errors.Is(err, ErrSearchInvalidStandID), errors.Is(err, ErrSearchInvalidStaffID):
+			response.BadRequest(c, "VALIDATION_ERROR", err.Error(), nil)
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, result)
+}
+
 func getFestivalID(c *gin.Context) (uuid.UUID, error) {
 	// First try from context (set by middleware)
 	festivalIDStr := c.GetString("festival_id")
@@ -30,6 +30,10 @@ type Repository interface {
 	GetFAQsByCategory(ctx context.Context, festivalID uuid.UUID, category string, publishedOnly bool) ([]FAQItem, error)
 	UpdateFAQ(ctx context.Context, faq *FAQItem) error
 	DeleteFAQ(ctx context.Context, id uuid.UUID) error
+
+	// Search operations
+	SearchOrders(ctx context.Context, festivalID uuid.UUID, filter SearchFilter, limit int) ([]OrderMatch, error)
+	SearchTransactions(ctx context.Context, festivalID uuid.UUID, filter SearchFilter, limit int) ([]TransactionMatch, error)
 }
 
 // TicketFilters represents filters for listing support tickets
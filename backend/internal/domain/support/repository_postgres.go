@@ -3,9 +3,11 @@ package support
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repository struct {
@@ -319,3 +321,92 @@ func (r *repository) DeleteFAQ(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// Search operations
+
+// SearchOrders finds the orders of a festival matching a support search, the closest
+// to filter.Around first, otherwise the latest first
+func (r *repository) SearchOrders(ctx context.Context, festivalID uuid.UUID, filter SearchFilter, limit int) ([]OrderMatch, error) {
+	query := r.db.WithContext(ctx).
+		Table("orders o").
+		Select(`o.id, o.stand_id, s.name AS stand_name, o.wallet_id, o.user_id, o.total_amount,
+			o.status, o.payment_method, o.transaction_id, o.staff_id, o.created_at`).
+		Joins("LEFT JOIN stands s ON s.id = o.stand_id").
+		Where("o.festival_id = ? AND o.created_at >= ? AND o.created_at < ?", festivalID, filter.From, filter.To)
+
+	if filter.AmountMin != nil {
+		query = query.Where("o.total_amount >= ?", *filter.AmountMin)
+	}
+	if filter.AmountMax != nil {
+		query = query.Where("o.total_amount <= ?", *filter.AmountMax)
+	}
+	if filter.StandID != nil {
+		query = query.Where("o.stand_id = ?", *filter.StandID)
+	}
+	if filter.StaffID != nil {
+		query = query.Where("o.staff_id = ?", *filter.StaffID)
+	}
+	if filter.CardLast4 != "" {
+		query = query.Where("o.wallet_id IN (?)", r.cardWallets(festivalID, filter.CardLast4))
+	}
+
+	var orders []OrderMatch
+	if err := searchOrder(query, "o.created_at", filter.Around).Limit(limit).Scan(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+	return orders, nil
+}
+
+// SearchTransactions finds the wallet transactions of a festival matching a support
+// search. Amounts are compared in absolute value, as debits are negative.
+func (r *repository) SearchTransactions(ctx context.Context, festivalID uuid.UUID, filter SearchFilter, limit int) ([]TransactionMatch, error) {
+	query := r.db.WithContext(ctx).
+		Table("transactions t").
+		Select(`t.id, t.wallet_id, t.type, t.amount, t.stand_id, s.name AS stand_name, t.staff_id,
+			t.reference, pi.card_last4, t.status, t.created_at`).
+		Joins("JOIN wallets w ON w.id = t.wallet_id").
+		Joins("LEFT JOIN stands s ON s.id = t.stand_id").
+		Joins("LEFT JOIN payment_intents pi ON pi.stripe_intent_id = t.reference AND t.type = 'TOP_UP'").
+		Where("w.festival_id = ? AND t.created_at >= ? AND t.created_at < ?", festivalID, filter.From, filter.To)
+
+	if filter.AmountMin != nil {
+		query = query.Where("ABS(t.amount) >= ?", *filter.AmountMin)
+	}
+	if filter.AmountMax != nil {
+		query = query.Where("ABS(t.amount) <= ?", *filter.AmountMax)
+	}
+	if filter.StandID != nil {
+		query = query.Where("t.stand_id = ?", *filter.StandID)
+	}
+	if filter.StaffID != nil {
+		query = query.Where("t.staff_id = ?", *filter.StaffID)
+	}
+	if filter.CardLast4 != "" {
+		query = query.Where("t.wallet_id IN (?)", r.cardWallets(festivalID, filter.CardLast4))
+	}
+
+	var transactions []TransactionMatch
+	if err := searchOrder(query, "t.created_at", filter.Around).Limit(limit).Scan(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// cardWallets selects the wallets of a festival topped up on Stripe with a card ending
+// in last4
+func (r *repository) cardWallets(festivalID uuid.UUID, last4 string) *gorm.DB {
+	return r.db.Table("payment_intents").
+		Select("wallet_id").
+		Where("festival_id = ? AND card_last4 = ? AND wallet_id IS NOT NULL", festivalID, last4)
+}
+
+// searchOrder sorts search matches by distance to around, or latest first
+func searchOrder(query *gorm.DB, column string, around *time.Time) *gorm.DB {
+	if around == nil {
+		return query.Order(column + " DESC")
+	}
+	return query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  "ABS(EXTRACT(EPOCH FROM (" + column + " - ?)))",
+		Vars: []interface{}{*around},
+	}})
+}
@@ -0,0 +1,261 @@
+package support
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+)
+
+// Search errors
+var (
+	ErrSearchTooBroad       = errors.New("give an amount, a stand, a staff member or the last 4 digits of a card")
+	ErrSearchWindowRequired = errors.New("give a time window, with from and to or with at")
+	ErrSearchWindowConflict = errors.New("give either at or from and to, not both")
+	ErrSearchWindowInvalid  = errors.New("to must be after from")
+	ErrSearchWindowTooWide  = errors.New("the time window spans at most 24 hours")
+	ErrSearchAmountConflict = errors.New("give either amount or amountMin and amountMax, not both")
+	ErrSearchAmountInvalid  = errors.New("amountMin must not be above amountMax")
+	ErrSearchInvalidStandID = errors.New("invalid stand ID")
+	ErrSearchInvalidStaffID = errors.New("invalid staff ID")
+)
+
+// PermissionSearch is the permission of the support staff allowed to search orders
+// and transactions
+const PermissionSearch = "support:search"
+
+// MaxSearchWindow bounds the time window of a support search, so that a search finds
+// a few candidates rather than browsing the history of the festival
+const MaxSearchWindow = 24 * time.Hour
+
+// DefaultSearchWindowMinutes is how far around at a search looks by default
+const DefaultSearchWindowMinutes = 15
+
+// DefaultSearchLimit is the number of orders and of transactions returned by default
+const DefaultSearchLimit = 50
+
+// Kinds of records a search returns
+const (
+	SearchKindOrders       = "orders"
+	SearchKindTransactions = "transactions"
+)
+
+// SearchQuery finds orders and transactions from what an attendee remembers, e.g.
+// "the 23.50€ order around 21:10 at Bar 2". Amounts are in cents.
+type SearchQuery struct {
+	Amount        *int64     `form:"amount" binding:"omitempty,min=1"` // Exact amount
+	AmountMin     *int64     `form:"amountMin" binding:"omitempty,min=0"`
+	AmountMax     *int64     `form:"amountMax" binding:"omitempty,min=0"`
+	At            *time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"` // Around this time, closest first
+	WindowMinutes int        `form:"windowMinutes" binding:"omitempty,min=1,max=120"`
+	From          *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To            *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	StandID       string     `form:"standId" binding:"omitempty,uuid"`
+	StaffID       string     `form:"staffId" binding:"omitempty,uuid"` // Staff member who processed it
+	CardLast4     string     `form:"cardLast4" binding:"omitempty,len=4,numeric"`
+	Kind          string     `form:"kind" binding:"omitempty,oneof=orders transactions"` // Both by default
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// SearchFilter is a validated SearchQuery
+type SearchFilter struct {
+	From      time.Time
+	To        time.Time
+	Around    *time.Time // Closest first, latest first otherwise
+	AmountMin *int64
+	AmountMax *int64
+	StandID   *uuid.UUID
+	StaffID   *uuid.UUID
+	CardLast4 string // Of the Stripe top-ups of the wallet
+}
+
+// OrderMatch is an order found by a support search
+type OrderMatch struct {
+	ID            uuid.UUID  `json:"id"`
+	StandID       uuid.UUID  `json:"standId"`
+	StandName     string     `json:"standName"`
+	WalletID      uuid.UUID  `json:"walletId"`
+	UserID        uuid.UUID  `json:"userId"`
+	TotalAmount   int64      `json:"totalAmount"`
+	Status        string     `json:"status"`
+	PaymentMethod string     `json:"paymentMethod"`
+	TransactionID *uuid.UUID `json:"transactionId,omitempty"`
+	StaffID       *uuid.UUID `json:"staffId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// TransactionMatch is a wallet transaction found by a support search
+type TransactionMatch struct {
+	ID        uuid.UUID  `json:"id"`
+	WalletID  uuid.UUID  `json:"walletId"`
+	Type      string     `json:"type"`
+	Amount    int64      `json:"amount"` // Negative for debits
+	StandID   *uuid.UUID `json:"standId,omitempty"`
+	StandName *string    `json:"standName,omitempty"`
+	StaffID   *uuid.UUID `json:"staffId,omitempty"`
+	Reference string     `json:"reference,omitempty"`
+	CardLast4 *string    `json:"cardLast4,omitempty"` // Card of a Stripe top-up
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// SearchResult lists the orders and transactions matching a search
+type SearchResult struct {
+	Orders       []OrderMatch       `json:"orders"`
+	Transactions []TransactionMatch `json:"transactions"`
+	Truncated    bool               `json:"truncated"` // More matches than the limit; narrow the search
+}
+
+// AuditLogger records the searches of support staff, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// SetAuditLogger records every search in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.auditLogger = logger
+}
+
+// Search finds the orders and wallet transactions of a festival matching the filters
+// of a query. A search needs a time window of at most a day and at least one other
+// filter, and is recorded in the audit log with its filters and the number of matches.
+func (s *Service) Search(ctx context.Context, festivalID uuid.UUID, query SearchQuery, staffID *uuid.UUID) (*SearchResult, error) {
+	filter, err := query.Filter()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+
+	result := &SearchResult{Orders: []OrderMatch{}, Transactions: []TransactionMatch{}}
+	if query.Kind != SearchKindTransactions {
+		orders, err := s.repo.SearchOrders(ctx, festivalID, filter, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(orders) > limit {
+			orders, result.Truncated = orders[:limit], true
+		}
+		result.Orders = orders
+	}
+	if query.Kind != SearchKindOrders {
+		transactions, err := s.repo.SearchTransactions(ctx, festivalID, filter, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(transactions) > limit {
+			transactions, result.Truncated = transactions[:limit], true
+		}
+		result.Transactions = transactions
+	}
+
+	if s.auditLogger != nil {
+		s.auditLogger.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     staffID,
+			Action:     audit.ActionSupportSearch,
+			Resource:   "support_search",
+			FestivalID: &festivalID,
+			Metadata: map[string]interface{}{
+				"filters":      query.auditFilters(filter),
+				"orders":       len(result.Orders),
+				"transactions": len(result.Transactions),
+				"truncated":    result.Truncated,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// Filter validates a query into a filter
+func (q SearchQuery) Filter() (SearchFilter, error) {
+	var filter SearchFilter
+
+	switch {
+	case q.At != nil && (q.From != nil || q.To != nil):
+		return filter, ErrSearchWindowConflict
+	case q.At != nil:
+		minutes := q.WindowMinutes
+		if minutes == 0 {
+			minutes = DefaultSearchWindowMinutes
+		}
+		window := time.Duration(minutes) * time.Minute
+		filter.From, filter.To = q.At.Add(-window), q.At.Add(window)
+		filter.Around = q.At
+	case q.From != nil && q.To != nil:
+		filter.From, filter.To = *q.From, *q.To
+	default:
+		return filter, ErrSearchWindowRequired
+	}
+	if !filter.To.After(filter.From) {
+		return filter, ErrSearchWindowInvalid
+	}
+	if filter.To.Sub(filter.From) > MaxSearchWindow {
+		return filter, ErrSearchWindowTooWide
+	}
+
+	if q.Amount != nil {
+		if q.AmountMin != nil || q.AmountMax != nil {
+			return filter, ErrSearchAmountConflict
+		}
+		filter.AmountMin, filter.AmountMax = q.Amount, q.Amount
+	} else {
+		if q.AmountMin != nil && q.AmountMax != nil && *q.AmountMin > *q.AmountMax {
+			return filter, ErrSearchAmountInvalid
+		}
+		filter.AmountMin, filter.AmountMax = q.AmountMin, q.AmountMax
+	}
+
+	if q.StandID != "" {
+		standID, err := uuid.Parse(q.StandID)
+		if err != nil {
+			return filter, ErrSearchInvalidStandID
+		}
+		filter.StandID = &standID
+	}
+	if q.StaffID != "" {
+		staffID, err := uuid.Parse(q.StaffID)
+		if err != nil {
+			return filter, ErrSearchInvalidStaffID
+		}
+		filter.StaffID = &staffID
+	}
+	filter.CardLast4 = q.CardLast4
+
+	if filter.AmountMin == nil && filter.AmountMax == nil && filter.StandID == nil && filter.StaffID == nil && filter.CardLast4 == "" {
+		return filter, ErrSearchTooBroad
+	}
+	return filter, nil
+}
+
+// auditFilters returns the filters of a search as recorded in the audit log
+func (q SearchQuery) auditFilters(filter SearchFilter) map[string]interface{} {
+	filters := map[string]interface{}{
+		"from": filter.From.Format(time.RFC3339),
+		"to":   filter.To.Format(time.RFC3339),
+	}
+	if filter.AmountMin != nil {
+		filters["amountMin"] = *filter.AmountMin
+	}
+	if filter.AmountMax != nil {
+		filters["amountMax"] = *filter.AmountMax
+	}
+	if q.StandID != "" {
+		filters["standId"] = q.StandID
+	}
+	if q.StaffID != "" {
+		filters["staffId"] = q.StaffID
+	}
+	if q.CardLast4 != "" {
+		filters["cardLast4"] = q.CardLast4
+	}
+	if q.Kind != "" {
+		filters["kind"] = q.Kind
+	}
+	return filters
+}
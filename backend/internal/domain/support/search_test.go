@@ -0,0 +1,119 @@
+package support
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearchRepository struct {
+	Repository
+	filter       SearchFilter
+	limit        int
+	orders       []OrderMatch
+	transactions []TransactionMatch
+}
+
+func (r *fakeSearchRepository) SearchOrders(ctx context.Context, festivalID uuid.UUID, filter SearchFilter, limit int) ([]OrderMatch, error) {
+	r.filter, r.limit = filter, limit
+	return r.orders, nil
+}
+
+func (r *fakeSearchRepository) SearchTransactions(ctx context.Context, festivalID uuid.UUID, filter SearchFilter, limit int) ([]TransactionMatch, error) {
+	r.filter, r.limit = filter, limit
+	return r.transactions, nil
+}
+
+type fakeAuditLogger struct {
+	logs []audit.CreateAuditLogRequest
+}
+
+func (l *fakeAuditLogger) LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest) {
+	l.logs = append(l.logs, req)
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestService_Search(t *testing.T) {
+	repo := &fakeSearchRepository{
+		orders:       []OrderMatch{{ID: uuid.New(), TotalAmount: 2350}},
+		transactions: []TransactionMatch{{ID: uuid.New(), Amount: -2350}, {ID: uuid.New(), Amount: -2350}},
+	}
+	auditLogger := &fakeAuditLogger{}
+	service := NewService(repo)
+	service.SetAuditLogger(auditLogger)
+
+	festivalID := uuid.New()
+	staffID := uuid.New()
+	standID := uuid.New()
+	at := time.Date(2026, 7, 18, 21, 10, 0, 0, time.UTC)
+
+	result, err := service.Search(context.Background(), festivalID, SearchQuery{
+		Amount:  int64Ptr(2350),
+		At:      &at,
+		StandID: standID.String(),
+		Limit:   1,
+	}, &staffID)
+	require.NoError(t, err)
+
+	assert.Len(t, result.Orders, 1)
+	assert.Len(t, result.Transactions, 1)
+	assert.True(t, result.Truncated)
+
+	assert.Equal(t, 2, repo.limit, "one more than the limit to tell if truncated")
+	assert.Equal(t, at.Add(-15*time.Minute), repo.filter.From)
+	assert.Equal(t, at.Add(15*time.Minute), repo.filter.To)
+	assert.Equal(t, &at, repo.filter.Around)
+	assert.Equal(t, int64(2350), *repo.filter.AmountMin)
+	assert.Equal(t, int64(2350), *repo.filter.AmountMax)
+	assert.Equal(t, &standID, repo.filter.StandID)
+
+	require.Len(t, auditLogger.logs, 1)
+	log := auditLogger.logs[0]
+	assert.Equal(t, audit.ActionSupportSearch, log.Action)
+	assert.Equal(t, &staffID, log.UserID)
+	assert.Equal(t, &festivalID, log.FestivalID)
+	assert.Equal(t, standID.String(), log.Metadata["filters"].(map[string]interface{})["standId"])
+	assert.Equal(t, 1, log.Metadata["transactions"])
+}
+
+func TestSearchQuery_Filter(t *testing.T) {
+	from := time.Date(2026, 7, 18, 20, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+
+	tests := []struct {
+		name  string
+		query SearchQuery
+		err   error
+	}{
+		{"no window", SearchQuery{CardLast4: "4242"}, ErrSearchWindowRequired},
+		{"no other filter", SearchQuery{From: &from, To: &to}, ErrSearchTooBroad},
+		{"at and from", SearchQuery{At: &from, From: &from, To: &to, CardLast4: "4242"}, ErrSearchWindowConflict},
+		{"backwards", SearchQuery{From: &to, To: &from, CardLast4: "4242"}, ErrSearchWindowInvalid},
+		{"too wide", SearchQuery{From: &from, To: timePtr(from.Add(25 * time.Hour)), CardLast4: "4242"}, ErrSearchWindowTooWide},
+		{"amount and range", SearchQuery{From: &from, To: &to, Amount: int64Ptr(100), AmountMin: int64Ptr(50)}, ErrSearchAmountConflict},
+		{"inverted range", SearchQuery{From: &from, To: &to, AmountMin: int64Ptr(500), AmountMax: int64Ptr(100)}, ErrSearchAmountInvalid},
+		{"valid", SearchQuery{From: &from, To: &to, AmountMin: int64Ptr(2000), AmountMax: int64Ptr(2500)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.query.Filter()
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
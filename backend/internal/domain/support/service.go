@@ -12,7 +12,8 @@ import (
 
 // Service handles business logic for support operations
 type Service struct {
-	repo Repository
+	repo        Repository
+	auditLogger AuditLogger
 }
 
 // NewService creates a new support service
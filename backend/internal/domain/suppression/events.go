@@ -0,0 +1,160 @@
+package suppression
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ProviderEvent is a bounce, complaint or opt-out reported by a delivery provider
+type ProviderEvent struct {
+	Channel Channel
+	Address string
+	Reason  Reason
+	Details string
+}
+
+// Twilio default opt-out and opt-in keywords
+var (
+	stopKeywords  = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	startKeywords = map[string]bool{"START": true, "YES": true, "UNSTOP": true}
+)
+
+// IsStopReply reports whether an inbound SMS asks to stop receiving messages
+func IsStopReply(body string) bool {
+	return stopKeywords[strings.ToUpper(strings.TrimSpace(body))]
+}
+
+// IsStartReply reports whether an inbound SMS asks to receive messages again
+func IsStartReply(body string) bool {
+	return startKeywords[strings.ToUpper(strings.TrimSpace(body))]
+}
+
+// postalWebhook is the envelope of a Postal webhook
+type postalWebhook struct {
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type postalMessage struct {
+	To string `json:"to"`
+}
+
+// ParsePostalEvent extracts a hard bounce from a Postal webhook. Other events return nil.
+func ParsePostalEvent(body []byte) (*ProviderEvent, error) {
+	var webhook postalWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to decode postal webhook: %w", err)
+	}
+
+	switch webhook.Event {
+	case "MessageBounced":
+		var payload struct {
+			OriginalMessage postalMessage `json:"original_message"`
+		}
+		if err := json.Unmarshal(webhook.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode postal bounce: %w", err)
+		}
+		return &ProviderEvent{
+			Channel: ChannelEmail,
+			Address: payload.OriginalMessage.To,
+			Reason:  ReasonHardBounce,
+			Details: "Postal: bounce received",
+		}, nil
+
+	case "MessageDeliveryFailed":
+		var payload struct {
+			Message postalMessage `json:"message"`
+			Status  string        `json:"status"`
+			Details string        `json:"details"`
+		}
+		if err := json.Unmarshal(webhook.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode postal delivery failure: %w", err)
+		}
+		// Soft failures are retried by Postal
+		if payload.Status != "HardFail" {
+			return nil, nil
+		}
+		return &ProviderEvent{
+			Channel: ChannelEmail,
+			Address: payload.Message.To,
+			Reason:  ReasonHardBounce,
+			Details: "Postal: " + payload.Details,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// sendGridEvent is an event of the SendGrid event webhook
+type sendGridEvent struct {
+	Email  string `json:"email"`
+	Event  string `json:"event"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	Status string `json:"status"`
+}
+
+// ParseSendGridEvents extracts hard bounces and spam reports from a SendGrid event webhook
+func ParseSendGridEvents(body []byte) ([]ProviderEvent, error) {
+	var events []sendGridEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode sendgrid events: %w", err)
+	}
+
+	var result []ProviderEvent
+	for _, e := range events {
+		switch {
+		case e.Event == "spamreport":
+			result = append(result, ProviderEvent{
+				Channel: ChannelEmail,
+				Address: e.Email,
+				Reason:  ReasonSpamComplaint,
+				Details: "SendGrid: spam report",
+			})
+		// "blocked" bounces are temporary rejections by the receiving server
+		case e.Event == "bounce" && e.Type != "blocked":
+			result = append(result, ProviderEvent{
+				Channel: ChannelEmail,
+				Address: e.Email,
+				Reason:  ReasonHardBounce,
+				Details: strings.TrimSpace("SendGrid: " + e.Status + " " + e.Reason),
+			})
+		}
+	}
+	return result, nil
+}
+
+// ValidateTwilioSignature checks the X-Twilio-Signature of a webhook request: the
+// base64 HMAC-SHA1 of the full URL followed by the sorted POST parameters.
+func ValidateTwilioSignature(authToken, requestURL string, params url.Values, signature string) bool {
+	if authToken == "" || signature == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(requestURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,135 @@
+package suppression
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeAddress tests the canonical form of suppressed addresses
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		channel  Channel
+		address  string
+		expected string
+		wantErr  bool
+	}{
+		{"email is lowercased", ChannelEmail, "  Jane.Doe@Example.COM ", "jane.doe@example.com", false},
+		{"email without domain", ChannelEmail, "jane@", "", true},
+		{"email without local part", ChannelEmail, "@example.com", "", true},
+		{"email with space", ChannelEmail, "jane doe@example.com", "", true},
+		{"international number", ChannelSMS, "+33 6 12 34 56 78", "+33612345678", false},
+		{"number with separators", ChannelSMS, "+1 (415) 555-0100", "+14155550100", false},
+		{"00 prefix", ChannelSMS, "0032470123456", "+32470123456", false},
+		{"number too short", ChannelSMS, "+3212", "", true},
+		{"number too long", ChannelSMS, "+1234567890123456", "", true},
+		{"unknown channel", Channel("PUSH"), "token", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAddress(tt.channel, tt.address)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAddress)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// TestStopAndStartReplies tests inbound SMS keyword detection
+func TestStopAndStartReplies(t *testing.T) {
+	for _, body := range []string{"STOP", "stop", " Unsubscribe\n", "StopAll"} {
+		assert.True(t, IsStopReply(body), body)
+		assert.False(t, IsStartReply(body), body)
+	}
+	for _, body := range []string{"START", "unstop", "Yes"} {
+		assert.True(t, IsStartReply(body), body)
+		assert.False(t, IsStopReply(body), body)
+	}
+	assert.False(t, IsStopReply("please stop sending"))
+	assert.False(t, IsStartReply(""))
+}
+
+// TestParsePostalEvent tests bounce extraction from Postal webhooks
+func TestParsePostalEvent(t *testing.T) {
+	t.Run("bounce", func(t *testing.T) {
+		event, err := ParsePostalEvent([]byte(`{"event":"MessageBounced","payload":{"original_message":{"to":"jane@example.com"},"bounce":{"to":"postmaster@example.com"}}}`))
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, ChannelEmail, event.Channel)
+		assert.Equal(t, "jane@example.com", event.Address)
+		assert.Equal(t, ReasonHardBounce, event.Reason)
+	})
+
+	t.Run("hard delivery failure", func(t *testing.T) {
+		event, err := ParsePostalEvent([]byte(`{"event":"MessageDeliveryFailed","payload":{"status":"HardFail","details":"550 No such user","message":{"to":"gone@example.com"}}}`))
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, "gone@example.com", event.Address)
+		assert.Equal(t, "Postal: 550 No such user", event.Details)
+	})
+
+	t.Run("soft delivery failure is ignored", func(t *testing.T) {
+		event, err := ParsePostalEvent([]byte(`{"event":"MessageDeliveryFailed","payload":{"status":"SoftFail","message":{"to":"busy@example.com"}}}`))
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("other events are ignored", func(t *testing.T) {
+		event, err := ParsePostalEvent([]byte(`{"event":"MessageSent","payload":{"message":{"to":"jane@example.com"}}}`))
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := ParsePostalEvent([]byte(`not json`))
+		assert.Error(t, err)
+	})
+}
+
+// TestParseSendGridEvents tests bounce and complaint extraction from SendGrid webhooks
+func TestParseSendGridEvents(t *testing.T) {
+	events, err := ParseSendGridEvents([]byte(`[
+		{"email":"jane@example.com","event":"delivered"},
+		{"email":"gone@example.com","event":"bounce","type":"bounce","status":"5.1.1","reason":"user unknown"},
+		{"email":"full@example.com","event":"bounce","type":"blocked","status":"4.2.2"},
+		{"email":"angry@example.com","event":"spamreport"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "gone@example.com", events[0].Address)
+	assert.Equal(t, ReasonHardBounce, events[0].Reason)
+	assert.Equal(t, "SendGrid: 5.1.1 user unknown", events[0].Details)
+
+	assert.Equal(t, "angry@example.com", events[1].Address)
+	assert.Equal(t, ReasonSpamComplaint, events[1].Reason)
+
+	_, err = ParseSendGridEvents([]byte(`{"event":"bounce"}`))
+	assert.Error(t, err)
+}
+
+// TestValidateTwilioSignature tests the webhook signature check against Twilio's documented example
+func TestValidateTwilioSignature(t *testing.T) {
+	requestURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	signature := "0/KCTR6DLpKmkAf8muzZqo1nDgQ="
+
+	assert.True(t, ValidateTwilioSignature("12345", requestURL, params, signature))
+	assert.False(t, ValidateTwilioSignature("54321", requestURL, params, signature))
+	assert.False(t, ValidateTwilioSignature("12345", requestURL+"&baz=3", params, signature))
+	assert.False(t, ValidateTwilioSignature("12345", requestURL, params, ""))
+	assert.False(t, ValidateTwilioSignature("", requestURL, params, signature))
+}
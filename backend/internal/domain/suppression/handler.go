@@ -0,0 +1,186 @@
+package suppression
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the admin suppression list routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	suppressions := r.Group("/suppressions")
+	{
+		suppressions.GET("", h.List)
+		suppressions.POST("", h.Add)
+		suppressions.GET("/stats", h.Stats)
+		suppressions.GET("/:id", h.GetByID)
+		suppressions.DELETE("/:id", h.Remove)
+	}
+}
+
+// List lists the suppressed addresses
+// @Summary List suppressed addresses
+// @Description Get the email addresses and phone numbers no message is sent to
+// @Tags suppressions
+// @Produce json
+// @Param channel query string false "Channel" Enums(EMAIL, SMS)
+// @Param reason query string false "Reason" Enums(HARD_BOUNCE, SPAM_COMPLAINT, UNSUBSCRIBED, MANUAL)
+// @Param search query string false "Address contains"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Entry} "Suppressed addresses"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/suppressions [get]
+func (h *Handler) List(c *gin.Context) {
+	var query ListEntriesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	entries, total, err := h.service.List(c.Request.Context(), query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, entries, &response.Meta{
+		Total:   int(total),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// Add adds an address to the suppression list
+// @Summary Suppress address
+// @Description Stop sending emails or SMS to an address; adding an existing address updates its reason
+// @Tags suppressions
+// @Accept json
+// @Produce json
+// @Param request body AddEntryRequest true "Address to suppress"
+// @Success 201 {object} response.Response{data=Entry} "Address suppressed"
+// @Failure 400 {object} response.ErrorResponse "Invalid address"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/suppressions [post]
+func (h *Handler) Add(c *gin.Context) {
+	var req AddEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	var adminID *uuid.UUID
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		adminID = &id
+	}
+
+	entry, err := h.service.Add(c.Request.Context(), adminID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, entry)
+}
+
+// GetByID gets a suppression entry
+// @Summary Get suppressed address
+// @Description Get a suppression entry with the number of deliveries it blocked
+// @Tags suppressions
+// @Produce json
+// @Param id path string true "Entry ID" format(uuid)
+// @Success 200 {object} response.Response{data=Entry} "Suppression entry"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Entry not found"
+// @Security BearerAuth
+// @Router /admin/suppressions/{id} [get]
+func (h *Handler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid suppression entry ID", nil)
+		return
+	}
+
+	entry, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, entry)
+}
+
+// Remove removes an address from the suppression list
+// @Summary Unsuppress address
+// @Description Remove an address from the suppression list so messages are sent to it again
+// @Tags suppressions
+// @Param id path string true "Entry ID" format(uuid)
+// @Success 204 "Address removed"
+// @Failure 400 {object} response.ErrorResponse "Invalid ID"
+// @Failure 404 {object} response.ErrorResponse "Entry not found"
+// @Security BearerAuth
+// @Router /admin/suppressions/{id} [delete]
+func (h *Handler) Remove(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid suppression entry ID", nil)
+		return
+	}
+
+	if err := h.service.Remove(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Stats returns suppression metrics
+// @Summary Get suppression stats
+// @Description Get the suppressed addresses per channel and reason and the share of deliveries skipped over the last days
+// @Tags suppressions
+// @Produce json
+// @Param days query int false "Period in days (max 31)" default(7)
+// @Success 200 {object} response.Response{data=Stats} "Suppression stats"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/suppressions/stats [get]
+func (h *Handler) Stats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(DefaultStatsDays)))
+
+	stats, err := h.service.GetStats(c.Request.Context(), days)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, stats)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEntryNotFound):
+		response.NotFound(c, "Suppression entry not found")
+	case errors.Is(err, ErrInvalidAddress):
+		response.BadRequest(c, "INVALID_ADDRESS", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,144 @@
+package suppression
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultStatsDays is the period covered by suppression rate statistics
+	DefaultStatsDays = 7
+	// MaxStatsDays is the longest period for which delivery counters are kept
+	MaxStatsDays = 31
+)
+
+// Suppression errors
+var (
+	ErrEntryNotFound  = errors.New("suppression entry not found")
+	ErrInvalidAddress = errors.New("invalid address for channel")
+)
+
+// Channel is the delivery channel an address belongs to
+type Channel string
+
+const (
+	ChannelEmail Channel = "EMAIL"
+	ChannelSMS   Channel = "SMS"
+)
+
+// Reason explains why an address is suppressed
+type Reason string
+
+const (
+	ReasonHardBounce    Reason = "HARD_BOUNCE"    // Address does not exist or cannot receive messages
+	ReasonSpamComplaint Reason = "SPAM_COMPLAINT" // Recipient reported a message as spam
+	ReasonUnsubscribed  Reason = "UNSUBSCRIBED"   // Recipient replied STOP
+	ReasonManual        Reason = "MANUAL"         // Added by an administrator
+)
+
+// Source is where a suppression came from
+type Source string
+
+const (
+	SourceProviderWebhook Source = "PROVIDER_WEBHOOK" // Bounce or complaint reported by the email provider
+	SourceInboundReply    Source = "INBOUND_REPLY"    // STOP reply received by the SMS provider
+	SourceDeliveryFailure Source = "DELIVERY_FAILURE" // Permanent failure returned when sending
+	SourceAdmin           Source = "ADMIN"
+)
+
+// Entry is a suppressed address; no email or SMS is sent to it on its channel
+type Entry struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Channel   Channel    `json:"channel" gorm:"not null;uniqueIndex:idx_suppressions_channel_address"`
+	Address   string     `json:"address" gorm:"not null;uniqueIndex:idx_suppressions_channel_address"`
+	Reason    Reason     `json:"reason" gorm:"not null"`
+	Source    Source     `json:"source" gorm:"not null"`
+	Details   string     `json:"details,omitempty"`
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	HitCount  int64      `json:"hitCount" gorm:"default:0"` // Deliveries skipped because of this entry
+	LastHitAt *time.Time `json:"lastHitAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (Entry) TableName() string {
+	return "suppressions"
+}
+
+// NormalizeAddress returns the canonical form of an address on a channel
+func NormalizeAddress(channel Channel, address string) (string, error) {
+	address = strings.TrimSpace(address)
+
+	switch channel {
+	case ChannelEmail:
+		address = strings.ToLower(address)
+		at := strings.LastIndex(address, "@")
+		if at < 1 || at == len(address)-1 || strings.ContainsAny(address, " \t") {
+			return "", ErrInvalidAddress
+		}
+		return address, nil
+	case ChannelSMS:
+		var b strings.Builder
+		for i, r := range address {
+			if r >= '0' && r <= '9' {
+				b.WriteRune(r)
+			} else if r == '+' && i == 0 {
+				continue
+			}
+		}
+		digits := b.String()
+		if strings.HasPrefix(digits, "00") {
+			digits = digits[2:]
+		}
+		if len(digits) < 8 || len(digits) > 15 {
+			return "", ErrInvalidAddress
+		}
+		return "+" + digits, nil
+	default:
+		return "", ErrInvalidAddress
+	}
+}
+
+// AddEntryRequest represents an administrator adding an address to the suppression list
+type AddEntryRequest struct {
+	Channel Channel `json:"channel" binding:"required,oneof=EMAIL SMS"`
+	Address string  `json:"address" binding:"required,max=320"`
+	Reason  Reason  `json:"reason" binding:"omitempty,oneof=HARD_BOUNCE SPAM_COMPLAINT UNSUBSCRIBED MANUAL"`
+	Details string  `json:"details" binding:"max=500"`
+}
+
+// ListEntriesQuery represents the query parameters for listing suppressed addresses
+type ListEntriesQuery struct {
+	Channel Channel `form:"channel" binding:"omitempty,oneof=EMAIL SMS"`
+	Reason  Reason  `form:"reason" binding:"omitempty,oneof=HARD_BOUNCE SPAM_COMPLAINT UNSUBSCRIBED MANUAL"`
+	Search  string  `form:"search"`
+	Page    int     `form:"page,default=1" binding:"min=1"`
+	PerPage int     `form:"per_page,default=20" binding:"min=1,max=100"`
+}
+
+// ReasonCount is the number of suppressed addresses per channel and reason
+type ReasonCount struct {
+	Channel Channel `json:"channel" gorm:"column:channel"`
+	Reason  Reason  `json:"reason" gorm:"column:reason"`
+	Count   int64   `json:"count" gorm:"column:count"`
+}
+
+// ChannelStats summarizes the suppression activity of a channel over a period
+type ChannelStats struct {
+	Channel         Channel `json:"channel"`
+	Suppressed      int64   `json:"suppressed"`      // Addresses currently on the list
+	Added           int64   `json:"added"`           // Addresses added during the period
+	Checked         int64   `json:"checked"`         // Deliveries checked during the period
+	Skipped         int64   `json:"skipped"`         // Deliveries skipped during the period
+	SuppressionRate float64 `json:"suppressionRate"` // Skipped / checked, in percent
+}
+
+// Stats summarizes the suppression list
+type Stats struct {
+	Days     int            `json:"days"`
+	Channels []ChannelStats `json:"channels"`
+	ByReason []ReasonCount  `json:"byReason"`
+}
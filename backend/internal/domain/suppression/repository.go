@@ -0,0 +1,141 @@
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Upsert(ctx context.Context, entry *Entry) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Entry, error)
+	GetByAddress(ctx context.Context, channel Channel, address string) (*Entry, error)
+	List(ctx context.Context, query ListEntriesQuery) ([]Entry, int64, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	RecordHit(ctx context.Context, id uuid.UUID, at time.Time) error
+	CountByReason(ctx context.Context) ([]ReasonCount, error)
+	CountAddedSince(ctx context.Context, since time.Time) (map[Channel]int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Upsert adds an address to the list or refreshes the reason of an existing entry
+func (r *repository) Upsert(ctx context.Context, entry *Entry) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel"}, {Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "source", "details", "created_by", "updated_at"}),
+	}).Create(entry).Error
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Entry, error) {
+	var entry Entry
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get suppression entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func (r *repository) GetByAddress(ctx context.Context, channel Channel, address string) (*Entry, error) {
+	var entry Entry
+	err := r.db.WithContext(ctx).Where("channel = ? AND address = ?", channel, address).First(&entry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get suppression entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func (r *repository) List(ctx context.Context, query ListEntriesQuery) ([]Entry, int64, error) {
+	var entries []Entry
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&Entry{})
+	if query.Channel != "" {
+		q = q.Where("channel = ?", query.Channel)
+	}
+	if query.Reason != "" {
+		q = q.Where("reason = ?", query.Reason)
+	}
+	if query.Search != "" {
+		q = q.Where("address ILIKE ?", "%"+query.Search+"%")
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count suppression entries: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	if err := q.Offset(offset).Limit(query.PerPage).Order("created_at DESC").Find(&entries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list suppression entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&Entry{}, "id = ?", id).Error
+}
+
+// RecordHit counts a delivery skipped because of an entry
+func (r *repository) RecordHit(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Entry{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"hit_count":   gorm.Expr("hit_count + 1"),
+			"last_hit_at": at,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record suppression hit: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) CountByReason(ctx context.Context) ([]ReasonCount, error) {
+	var counts []ReasonCount
+	err := r.db.WithContext(ctx).Model(&Entry{}).
+		Select("channel, reason, COUNT(*) as count").
+		Group("channel, reason").
+		Order("channel, reason").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count suppression entries: %w", err)
+	}
+	return counts, nil
+}
+
+func (r *repository) CountAddedSince(ctx context.Context, since time.Time) (map[Channel]int64, error) {
+	var rows []struct {
+		Channel Channel
+		Count   int64
+	}
+	err := r.db.WithContext(ctx).Model(&Entry{}).
+		Select("channel, COUNT(*) as count").
+		Where("created_at >= ?", since).
+		Group("channel").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count added suppression entries: %w", err)
+	}
+
+	counts := make(map[Channel]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Channel] = row.Count
+	}
+	return counts, nil
+}
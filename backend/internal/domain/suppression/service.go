@@ -0,0 +1,245 @@
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/cache"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Fields of the daily delivery counters
+const (
+	counterChecked = "checked"
+	counterSkipped = "skipped"
+)
+
+type Service struct {
+	repo        Repository
+	redisClient *redis.Client
+	keyBuilder  *cache.KeyBuilder
+}
+
+// NewService creates a suppression service. The Redis client keeps the daily delivery
+// counters used for suppression rates and may be nil.
+func NewService(repo Repository, redisClient *redis.Client) *Service {
+	return &Service{
+		repo:        repo,
+		redisClient: redisClient,
+		keyBuilder:  cache.NewKeyBuilder("festivals"),
+	}
+}
+
+// IsSuppressed reports whether deliveries to an address must be skipped. Every check is
+// counted for the suppression rate metrics.
+func (s *Service) IsSuppressed(ctx context.Context, channel Channel, address string) (bool, error) {
+	normalized, err := NormalizeAddress(channel, address)
+	if err != nil {
+		// Invalid addresses are left to the provider to reject
+		return false, nil
+	}
+
+	entry, err := s.repo.GetByAddress(ctx, channel, normalized)
+	if err != nil {
+		return false, err
+	}
+
+	suppressed := entry != nil
+	reason := ""
+	if suppressed {
+		reason = string(entry.Reason)
+		if err := s.repo.RecordHit(ctx, entry.ID, time.Now()); err != nil {
+			log.Warn().Err(err).Str("channel", string(channel)).Msg("Failed to record suppression hit")
+		}
+	}
+
+	s.countDelivery(ctx, channel, suppressed)
+	if m := monitoring.Get(); m != nil {
+		m.RecordDeliveryCheck(string(channel), suppressed, reason)
+	}
+
+	return suppressed, nil
+}
+
+// Suppress adds an address to the suppression list of a channel
+func (s *Service) Suppress(ctx context.Context, channel Channel, address string, reason Reason, source Source, details string, createdBy *uuid.UUID) (*Entry, error) {
+	normalized, err := NormalizeAddress(channel, address)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &Entry{
+		ID:        uuid.New(),
+		Channel:   channel,
+		Address:   normalized,
+		Reason:    reason,
+		Source:    source,
+		Details:   details,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.Upsert(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to suppress address: %w", err)
+	}
+
+	if m := monitoring.Get(); m != nil {
+		m.RecordSuppressionAdded(string(channel), string(reason), string(source))
+	}
+	log.Info().
+		Str("channel", string(channel)).
+		Str("reason", string(reason)).
+		Str("source", string(source)).
+		Msg("Address added to suppression list")
+
+	// The entry may already have existed, so return the stored one
+	return s.repo.GetByAddress(ctx, channel, normalized)
+}
+
+// RecordFailure suppresses an address after a permanent delivery failure
+func (s *Service) RecordFailure(ctx context.Context, channel Channel, address string, reason Reason, details string) error {
+	_, err := s.Suppress(ctx, channel, address, reason, SourceDeliveryFailure, details, nil)
+	return err
+}
+
+// Unsuppress removes an address from the list of a channel, e.g. after a START reply
+func (s *Service) Unsuppress(ctx context.Context, channel Channel, address string) error {
+	normalized, err := NormalizeAddress(channel, address)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.repo.GetByAddress(ctx, channel, normalized)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return nil
+	}
+	return s.repo.Delete(ctx, entry.ID)
+}
+
+// Add adds an address to the list on behalf of an administrator
+func (s *Service) Add(ctx context.Context, adminID *uuid.UUID, req AddEntryRequest) (*Entry, error) {
+	reason := req.Reason
+	if reason == "" {
+		reason = ReasonManual
+	}
+	return s.Suppress(ctx, req.Channel, req.Address, reason, SourceAdmin, req.Details, adminID)
+}
+
+// Get returns a suppression entry
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Entry, error) {
+	entry, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+	return entry, nil
+}
+
+// List lists the suppressed addresses
+func (s *Service) List(ctx context.Context, query ListEntriesQuery) ([]Entry, int64, error) {
+	return s.repo.List(ctx, query)
+}
+
+// Remove removes an entry so deliveries to the address resume
+func (s *Service) Remove(ctx context.Context, id uuid.UUID) error {
+	entry, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, entry.ID); err != nil {
+		return fmt.Errorf("failed to remove suppression entry: %w", err)
+	}
+
+	log.Info().Str("channel", string(entry.Channel)).Str("reason", string(entry.Reason)).Msg("Address removed from suppression list")
+	return nil
+}
+
+// GetStats summarizes the list and the share of deliveries skipped over the last days
+func (s *Service) GetStats(ctx context.Context, days int) (*Stats, error) {
+	if days < 1 || days > MaxStatsDays {
+		days = DefaultStatsDays
+	}
+
+	byReason, err := s.repo.CountByReason(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	added, err := s.repo.CountAddedSince(ctx, now.AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{Days: days, ByReason: byReason}
+	for _, channel := range []Channel{ChannelEmail, ChannelSMS} {
+		cs := ChannelStats{Channel: channel, Added: added[channel]}
+		for _, rc := range byReason {
+			if rc.Channel == channel {
+				cs.Suppressed += rc.Count
+			}
+		}
+
+		cs.Checked, cs.Skipped = s.deliveryCounts(ctx, channel, now, days)
+		if cs.Checked > 0 {
+			cs.SuppressionRate = math.Round(float64(cs.Skipped)/float64(cs.Checked)*10000) / 100
+		}
+		stats.Channels = append(stats.Channels, cs)
+	}
+
+	return stats, nil
+}
+
+// countDelivery increments the daily counters shared by the API and the workers
+func (s *Service) countDelivery(ctx context.Context, channel Channel, suppressed bool) {
+	if s.redisClient == nil {
+		return
+	}
+
+	key := s.keyBuilder.DeliveryCountersKey(string(channel), time.Now().UTC().Format("2006-01-02"))
+	pipe := s.redisClient.Pipeline()
+	pipe.HIncrBy(ctx, key, counterChecked, 1)
+	if suppressed {
+		pipe.HIncrBy(ctx, key, counterSkipped, 1)
+	}
+	pipe.Expire(ctx, key, (MaxStatsDays+1)*24*time.Hour)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn().Err(err).Str("channel", string(channel)).Msg("Failed to count delivery check")
+	}
+}
+
+func (s *Service) deliveryCounts(ctx context.Context, channel Channel, now time.Time, days int) (int64, int64) {
+	if s.redisClient == nil {
+		return 0, 0
+	}
+
+	var checked, skipped int64
+	for i := 0; i < days; i++ {
+		key := s.keyBuilder.DeliveryCountersKey(string(channel), now.AddDate(0, 0, -i).Format("2006-01-02"))
+		values, err := s.redisClient.HGetAll(ctx, key).Result()
+		if err != nil {
+			log.Warn().Err(err).Str("channel", string(channel)).Msg("Failed to read delivery counters")
+			continue
+		}
+		c, _ := strconv.ParseInt(values[counterChecked], 10, 64)
+		k, _ := strconv.ParseInt(values[counterSkipped], 10, 64)
+		checked += c
+		skipped += k
+	}
+	return checked, skipped
+}
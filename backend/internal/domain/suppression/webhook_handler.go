@@ -0,0 +1,161 @@
+package suppression
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookConfig holds the secrets used to authenticate provider webhooks
+type WebhookConfig struct {
+	EmailSecret     string // Shared token expected from the email provider webhooks
+	TwilioAuthToken string // Signs the Twilio inbound message webhook
+}
+
+// WebhookHandler receives bounces, complaints and opt-outs from delivery providers
+type WebhookHandler struct {
+	service *Service
+	config  WebhookConfig
+}
+
+func NewWebhookHandler(service *Service, config WebhookConfig) *WebhookHandler {
+	return &WebhookHandler{service: service, config: config}
+}
+
+// RegisterWebhookRoutes registers the provider webhook endpoints (no auth required)
+func (h *WebhookHandler) RegisterWebhookRoutes(r *gin.RouterGroup) {
+	r.POST("/email/postal", h.HandlePostal)
+	r.POST("/email/sendgrid", h.HandleSendGrid)
+	r.POST("/sms/twilio", h.HandleTwilioInbound)
+}
+
+// HandlePostal processes Postal bounce and delivery failure webhooks
+func (h *WebhookHandler) HandlePostal(c *gin.Context) {
+	if !h.authorizeEmail(c) {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.BadRequest(c, "INVALID_BODY", "Failed to read body", nil)
+		return
+	}
+
+	event, err := ParsePostalEvent(body)
+	if err != nil {
+		response.BadRequest(c, "INVALID_PAYLOAD", err.Error(), nil)
+		return
+	}
+	if event != nil {
+		h.apply(c, *event)
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandleSendGrid processes SendGrid event webhooks
+func (h *WebhookHandler) HandleSendGrid(c *gin.Context) {
+	if !h.authorizeEmail(c) {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.BadRequest(c, "INVALID_BODY", "Failed to read body", nil)
+		return
+	}
+
+	events, err := ParseSendGridEvents(body)
+	if err != nil {
+		response.BadRequest(c, "INVALID_PAYLOAD", err.Error(), nil)
+		return
+	}
+	for _, event := range events {
+		h.apply(c, event)
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandleTwilioInbound processes inbound SMS and handles STOP and START replies
+func (h *WebhookHandler) HandleTwilioInbound(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		response.BadRequest(c, "INVALID_BODY", "Failed to parse form", nil)
+		return
+	}
+
+	if !ValidateTwilioSignature(h.config.TwilioAuthToken, requestURL(c), c.Request.PostForm, c.GetHeader("X-Twilio-Signature")) {
+		response.Unauthorized(c, "Invalid signature")
+		return
+	}
+
+	from := c.Request.PostForm.Get("From")
+	body := c.Request.PostForm.Get("Body")
+
+	switch {
+	case IsStopReply(body):
+		h.apply(c, ProviderEvent{
+			Channel: ChannelSMS,
+			Address: from,
+			Reason:  ReasonUnsubscribed,
+			Details: "Replied " + body,
+		})
+	case IsStartReply(body):
+		if err := h.service.Unsuppress(c.Request.Context(), ChannelSMS, from); err != nil {
+			log.Error().Err(err).Msg("Failed to unsuppress phone number after START reply")
+		}
+	}
+
+	// Empty TwiML response, the provider sends its own opt-out confirmation
+	c.Data(http.StatusOK, "text/xml", []byte("<Response></Response>"))
+}
+
+func (h *WebhookHandler) apply(c *gin.Context, event ProviderEvent) {
+	source := SourceProviderWebhook
+	if event.Channel == ChannelSMS {
+		source = SourceInboundReply
+	}
+
+	if _, err := h.service.Suppress(c.Request.Context(), event.Channel, event.Address, event.Reason, source, event.Details, nil); err != nil {
+		log.Warn().Err(err).Str("channel", string(event.Channel)).Str("reason", string(event.Reason)).Msg("Failed to apply provider suppression event")
+	}
+}
+
+// authorizeEmail checks the shared token of the email provider webhooks
+func (h *WebhookHandler) authorizeEmail(c *gin.Context) bool {
+	if h.config.EmailSecret == "" {
+		response.ServiceUnavailable(c, "email webhook secret not configured")
+		return false
+	}
+
+	token := c.GetHeader("X-Webhook-Token")
+	if token == "" {
+		token = c.Query("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.config.EmailSecret)) != 1 {
+		response.Unauthorized(c, "Invalid webhook token")
+		return false
+	}
+	return true
+}
+
+// requestURL rebuilds the public URL Twilio signed, honoring proxy headers
+func requestURL(c *gin.Context) string {
+	scheme := "https"
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if c.Request.TLS == nil {
+		scheme = "http"
+	}
+
+	host := c.Request.Host
+	if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+
+	return scheme + "://" + host + c.Request.URL.RequestURI()
+}
@@ -0,0 +1,469 @@
+package survey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers festival-scoped survey routes
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	// Organizer survey builder
+	surveys := r.Group("/surveys")
+	{
+		surveys.POST("", h.Create)
+		surveys.GET("", h.List)
+		surveys.GET("/:surveyId", h.GetByID)
+		surveys.PATCH("/:surveyId", h.Update)
+		surveys.DELETE("/:surveyId", h.Delete)
+		surveys.POST("/:surveyId/publish", h.Publish)
+		surveys.POST("/:surveyId/close", h.Close)
+		surveys.GET("/:surveyId/results", h.Results)
+	}
+
+	// Attendee surveys
+	me := r.Group("/me/surveys")
+	{
+		me.GET("", h.ListAvailable)
+		me.GET("/:surveyId", h.GetForAttendee)
+		me.POST("/:surveyId/responses", h.Submit)
+	}
+}
+
+// Create creates a draft survey
+// @Summary Create survey
+// @Description Create a draft survey with rating, multiple choice and text questions, optionally targeted to a segment of attendees
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateSurveyRequest true "Survey"
+// @Success 201 {object} response.Response{data=SurveyResponse} "Survey created"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateSurveyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		createdBy = &userID
+	}
+
+	survey, err := h.service.Create(c.Request.Context(), festivalID, createdBy, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, survey.ToResponse())
+}
+
+// List lists the surveys of the festival
+// @Summary List surveys
+// @Description Get the surveys of the festival, optionally filtered by status
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "Survey status" Enums(DRAFT, PUBLISHED, CLOSED)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]SurveyResponse} "Surveys"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query ListSurveysQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	surveys, total, err := h.service.List(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]SurveyResponse, len(surveys))
+	for i := range surveys {
+		items[i] = surveys[i].ToResponse()
+	}
+
+	response.OKWithMeta(c, items, &response.Meta{
+		Total:   int(total),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// GetByID gets a survey
+// @Summary Get survey
+// @Description Get a survey with its questions and segment
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Success 200 {object} response.Response{data=SurveyResponse} "Survey"
+// @Failure 400 {object} response.ErrorResponse "Invalid survey ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys/{surveyId} [get]
+func (h *Handler) GetByID(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+
+	survey, err := h.service.Get(c.Request.Context(), festivalID, surveyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, survey.ToResponse())
+}
+
+// Update updates a draft survey
+// @Summary Update survey
+// @Description Update a draft survey; published and closed surveys can no longer be edited
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Param request body UpdateSurveyRequest true "Survey changes"
+// @Success 200 {object} response.Response{data=SurveyResponse} "Survey updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Failure 409 {object} response.ErrorResponse "Survey is not a draft"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys/{surveyId} [patch]
+func (h *Handler) Update(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateSurveyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	survey, err := h.service.Update(c.Request.Context(), festivalID, surveyID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, survey.ToResponse())
+}
+
+// Delete deletes a survey
+// @Summary Delete survey
+// @Description Delete a survey together with its responses
+// @Tags surveys
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Success 204 "Survey deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid survey ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys/{surveyId} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), festivalID, surveyID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Publish publishes a draft survey
+// @Summary Publish survey
+// @Description Make a draft survey available to its segment between its opening and closing dates
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Success 200 {object} response.Response{data=SurveyResponse} "Survey published"
+// @Failure 400 {object} response.ErrorResponse "Survey already closed"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Failure 409 {object} response.ErrorResponse "Survey is not a draft"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys/{surveyId}/publish [post]
+func (h *Handler) Publish(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+
+	survey, err := h.service.Publish(c.Request.Context(), festivalID, surveyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, survey.ToResponse())
+}
+
+// Close closes a survey
+// @Summary Close survey
+// @Description Stop accepting responses to a survey
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Success 200 {object} response.Response{data=SurveyResponse} "Survey closed"
+// @Failure 400 {object} response.ErrorResponse "Invalid survey ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys/{surveyId}/close [post]
+func (h *Handler) Close(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+
+	survey, err := h.service.Close(c.Request.Context(), festivalID, surveyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, survey.ToResponse())
+}
+
+// Results returns the aggregated results of a survey
+// @Summary Get survey results
+// @Description Get the answer distribution per question, the average of rating questions and the latest text answers
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Success 200 {object} response.Response{data=Results} "Survey results"
+// @Failure 400 {object} response.ErrorResponse "Invalid survey ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/surveys/{surveyId}/results [get]
+func (h *Handler) Results(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+
+	results, err := h.service.Results(c.Request.Context(), festivalID, surveyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, results)
+}
+
+// ListAvailable lists the surveys the attendee is invited to answer
+// @Summary List my surveys
+// @Description Get the open surveys targeting the attendee that they have not answered yet
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]AttendeeSurveyResponse} "Surveys"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/surveys [get]
+func (h *Handler) ListAvailable(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	surveys, err := h.service.ListAvailable(c.Request.Context(), festivalID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]AttendeeSurveyResponse, len(surveys))
+	for i := range surveys {
+		items[i] = surveys[i].ToAttendeeResponse()
+	}
+
+	response.OK(c, items)
+}
+
+// GetForAttendee gets an open survey to answer
+// @Summary Get my survey
+// @Description Get the questions of an open survey targeting the attendee
+// @Tags surveys
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Success 200 {object} response.Response{data=AttendeeSurveyResponse} "Survey"
+// @Failure 400 {object} response.ErrorResponse "Survey not open"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Survey not available to the attendee"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/surveys/{surveyId} [get]
+func (h *Handler) GetForAttendee(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	survey, err := h.service.GetForAttendee(c.Request.Context(), festivalID, userID, surveyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, survey.ToAttendeeResponse())
+}
+
+// Submit answers a survey
+// @Summary Answer survey
+// @Description Submit the answers of the attendee; a survey can only be answered once
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param surveyId path string true "Survey ID" format(uuid)
+// @Param request body SubmitRequest true "Answers"
+// @Success 201 {object} response.Response{data=SubmissionResponse} "Answers recorded"
+// @Failure 400 {object} response.ErrorResponse "Invalid answers or survey not open"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Survey not available to the attendee"
+// @Failure 404 {object} response.ErrorResponse "Survey not found"
+// @Failure 409 {object} response.ErrorResponse "Survey already answered"
+// @Failure 429 {object} response.ErrorResponse "Too many submissions"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/surveys/{surveyId}/responses [post]
+func (h *Handler) Submit(c *gin.Context) {
+	festivalID, surveyID, ok := h.festivalAndSurvey(c)
+	if !ok {
+		return
+	}
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req SubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	submission, err := h.service.Submit(c.Request.Context(), festivalID, userID, surveyID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, submission.ToResponse())
+}
+
+func (h *Handler) festivalAndSurvey(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	surveyID, err := uuid.Parse(c.Param("surveyId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid survey ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return festivalID, surveyID, true
+}
+
+func (h *Handler) userID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrSurveyNotFound):
+		response.NotFound(c, "Survey not found")
+	case errors.Is(err, ErrSurveyNotEditable),
+		errors.Is(err, ErrSurveyNotDraft):
+		response.Conflict(c, "SURVEY_NOT_DRAFT", err.Error())
+	case errors.Is(err, ErrAlreadyResponded):
+		response.Conflict(c, "SURVEY_ALREADY_ANSWERED", err.Error())
+	case errors.Is(err, ErrNotInSegment):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrTooManySubmissions):
+		response.TooManyRequests(c, int(time.Hour.Seconds()))
+	case errors.Is(err, ErrSurveyNotOpen):
+		response.BadRequest(c, "SURVEY_NOT_OPEN", err.Error(), nil)
+	case errors.Is(err, ErrInvalidQuestions):
+		response.BadRequest(c, "INVALID_QUESTIONS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidAnswers):
+		response.BadRequest(c, "INVALID_ANSWERS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidSchedule):
+		response.BadRequest(c, "INVALID_SCHEDULE", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
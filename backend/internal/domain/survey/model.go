@@ -0,0 +1,299 @@
+package survey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxQuestions is the maximum number of questions in a survey
+	MaxQuestions = 50
+	// MaxOptions is the maximum number of options of a multiple choice question
+	MaxOptions = 20
+	// MaxTextLength is the maximum length of a text answer
+	MaxTextLength = 2000
+	// DefaultRatingScale is the rating scale used when none is given
+	DefaultRatingScale = 5
+	// MaxRatingScale is the largest allowed rating scale
+	MaxRatingScale = 10
+	// MaxSubmissionsPerHour limits how many surveys one attendee can answer
+	MaxSubmissionsPerHour = 20
+	// TextAnswersPerQuestion is how many recent text answers the results include
+	TextAnswersPerQuestion = 20
+)
+
+// Survey errors
+var (
+	ErrSurveyNotFound     = errors.New("survey not found")
+	ErrSurveyNotEditable  = errors.New("only draft surveys can be edited")
+	ErrSurveyNotDraft     = errors.New("survey is not a draft")
+	ErrSurveyNotOpen      = errors.New("survey is not open for responses")
+	ErrNotInSegment       = errors.New("survey is not available to this attendee")
+	ErrAlreadyResponded   = errors.New("survey already answered")
+	ErrTooManySubmissions = errors.New("too many survey submissions, try again later")
+	ErrInvalidQuestions   = errors.New("invalid survey questions")
+	ErrInvalidAnswers     = errors.New("invalid survey answers")
+	ErrInvalidSchedule    = errors.New("survey must close after it opens")
+)
+
+// Status is the lifecycle status of a survey
+type Status string
+
+const (
+	StatusDraft     Status = "DRAFT"     // Being built, not visible to attendees
+	StatusPublished Status = "PUBLISHED" // Visible to its segment between opensAt and closesAt
+	StatusClosed    Status = "CLOSED"    // No longer accepting responses
+)
+
+// QuestionType is the kind of answer a question expects
+type QuestionType string
+
+const (
+	QuestionTypeRating         QuestionType = "RATING"
+	QuestionTypeMultipleChoice QuestionType = "MULTIPLE_CHOICE"
+	QuestionTypeText           QuestionType = "TEXT"
+)
+
+// Question is a survey question, stored with the survey
+type Question struct {
+	ID              uuid.UUID    `json:"id"`
+	Type            QuestionType `json:"type"`
+	Prompt          string       `json:"prompt"`
+	Required        bool         `json:"required"`
+	Options         []string     `json:"options,omitempty"`         // Multiple choice only
+	MultipleAnswers bool         `json:"multipleAnswers,omitempty"` // Multiple choice only
+	Scale           int          `json:"scale,omitempty"`           // Rating only, answers range from 1 to Scale
+}
+
+// Segment restricts a survey to part of the festival attendees. Every attendee holding
+// a valid ticket is targeted when the segment is empty.
+type Segment struct {
+	TicketTypeIDs []uuid.UUID `json:"ticketTypeIds,omitempty"` // Holders of one of these ticket types
+	CheckedInOnly bool        `json:"checkedInOnly,omitempty"` // Attendees who entered the festival
+	MinSpend      int64       `json:"minSpend,omitempty"`      // Paid orders total in cents
+}
+
+// Survey is a questionnaire built by organizers
+type Survey struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Title       string     `json:"title" gorm:"not null"`
+	Description string     `json:"description"`
+	Status      Status     `json:"status" gorm:"default:'DRAFT'"`
+	Questions   []Question `json:"questions" gorm:"type:jsonb;serializer:json"`
+	Segment     Segment    `json:"segment" gorm:"type:jsonb;serializer:json"`
+	OpensAt     *time.Time `json:"opensAt,omitempty"`
+	ClosesAt    *time.Time `json:"closesAt,omitempty"`
+	CreatedBy   *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Survey) TableName() string {
+	return "surveys"
+}
+
+// IsOpen reports whether the survey accepts responses at the given time
+func (s *Survey) IsOpen(now time.Time) bool {
+	if s.Status != StatusPublished {
+		return false
+	}
+	if s.OpensAt != nil && now.Before(*s.OpensAt) {
+		return false
+	}
+	if s.ClosesAt != nil && !now.Before(*s.ClosesAt) {
+		return false
+	}
+	return true
+}
+
+// Submission is the set of answers of one attendee to a survey
+type Submission struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SurveyID    uuid.UUID `json:"surveyId" gorm:"type:uuid;not null;uniqueIndex:idx_survey_submissions_user"`
+	FestivalID  uuid.UUID `json:"festivalId" gorm:"type:uuid;not null"`
+	UserID      uuid.UUID `json:"userId" gorm:"type:uuid;not null;uniqueIndex:idx_survey_submissions_user"`
+	Answers     []Answer  `json:"answers,omitempty" gorm:"foreignKey:SubmissionID"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+func (Submission) TableName() string {
+	return "survey_submissions"
+}
+
+// Answer is the answer to one question of a submission
+type Answer struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SubmissionID uuid.UUID `json:"submissionId" gorm:"type:uuid;not null;index"`
+	SurveyID     uuid.UUID `json:"surveyId" gorm:"type:uuid;not null;index"`
+	QuestionID   uuid.UUID `json:"questionId" gorm:"type:uuid;not null"`
+	Rating       *int      `json:"rating,omitempty"`
+	Choices      []string  `json:"choices,omitempty" gorm:"type:jsonb;serializer:json"`
+	Text         string    `json:"text,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (Answer) TableName() string {
+	return "survey_answers"
+}
+
+// AnswerCount is how many answers of a question had a given rating or choice
+type AnswerCount struct {
+	QuestionID uuid.UUID `gorm:"column:question_id"`
+	Value      string    `gorm:"column:value"`
+	Count      int64     `gorm:"column:count"`
+}
+
+// TextAnswer is a text answer of a question
+type TextAnswer struct {
+	QuestionID uuid.UUID `gorm:"column:question_id"`
+	Text       string    `gorm:"column:text"`
+}
+
+// QuestionInput describes a question when building a survey
+type QuestionInput struct {
+	Type            QuestionType `json:"type" binding:"required,oneof=RATING MULTIPLE_CHOICE TEXT"`
+	Prompt          string       `json:"prompt" binding:"required,max=500"`
+	Required        bool         `json:"required"`
+	Options         []string     `json:"options,omitempty"`
+	MultipleAnswers bool         `json:"multipleAnswers,omitempty"`
+	Scale           int          `json:"scale,omitempty"`
+}
+
+// CreateSurveyRequest represents the request to create a survey
+type CreateSurveyRequest struct {
+	Title       string          `json:"title" binding:"required,max=200"`
+	Description string          `json:"description" binding:"max=2000"`
+	Questions   []QuestionInput `json:"questions" binding:"required,min=1,dive"`
+	Segment     *Segment        `json:"segment,omitempty"`
+	OpensAt     *time.Time      `json:"opensAt,omitempty"`
+	ClosesAt    *time.Time      `json:"closesAt,omitempty"`
+}
+
+// UpdateSurveyRequest represents the request to update a draft survey
+type UpdateSurveyRequest struct {
+	Title       *string         `json:"title,omitempty" binding:"omitempty,max=200"`
+	Description *string         `json:"description,omitempty" binding:"omitempty,max=2000"`
+	Questions   []QuestionInput `json:"questions,omitempty" binding:"omitempty,min=1,dive"`
+	Segment     *Segment        `json:"segment,omitempty"`
+	OpensAt     *time.Time      `json:"opensAt,omitempty"`
+	ClosesAt    *time.Time      `json:"closesAt,omitempty"`
+}
+
+// AnswerInput is the answer to one question in a submission
+type AnswerInput struct {
+	QuestionID uuid.UUID `json:"questionId" binding:"required"`
+	Rating     *int      `json:"rating,omitempty"`
+	Choices    []string  `json:"choices,omitempty"`
+	Text       string    `json:"text,omitempty"`
+}
+
+// SubmitRequest represents an attendee's answers to a survey
+type SubmitRequest struct {
+	Answers []AnswerInput `json:"answers" binding:"required,dive"`
+}
+
+// ListSurveysQuery represents the query parameters for listing surveys
+type ListSurveysQuery struct {
+	Status  Status `form:"status" binding:"omitempty,oneof=DRAFT PUBLISHED CLOSED"`
+	Page    int    `form:"page,default=1" binding:"min=1"`
+	PerPage int    `form:"per_page,default=20" binding:"min=1,max=100"`
+}
+
+// SurveyResponse represents the API response for a survey
+type SurveyResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	FestivalID  uuid.UUID  `json:"festivalId"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      Status     `json:"status"`
+	Questions   []Question `json:"questions"`
+	Segment     Segment    `json:"segment"`
+	OpensAt     *string    `json:"opensAt,omitempty"`
+	ClosesAt    *string    `json:"closesAt,omitempty"`
+	PublishedAt *string    `json:"publishedAt,omitempty"`
+	CreatedAt   string     `json:"createdAt"`
+	UpdatedAt   string     `json:"updatedAt"`
+}
+
+func (s *Survey) ToResponse() SurveyResponse {
+	return SurveyResponse{
+		ID:          s.ID,
+		FestivalID:  s.FestivalID,
+		Title:       s.Title,
+		Description: s.Description,
+		Status:      s.Status,
+		Questions:   s.Questions,
+		Segment:     s.Segment,
+		OpensAt:     formatTime(s.OpensAt),
+		ClosesAt:    formatTime(s.ClosesAt),
+		PublishedAt: formatTime(s.PublishedAt),
+		CreatedAt:   s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   s.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// AttendeeSurveyResponse represents a survey as shown to attendees
+type AttendeeSurveyResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Questions   []Question `json:"questions"`
+	ClosesAt    *string    `json:"closesAt,omitempty"`
+}
+
+func (s *Survey) ToAttendeeResponse() AttendeeSurveyResponse {
+	return AttendeeSurveyResponse{
+		ID:          s.ID,
+		Title:       s.Title,
+		Description: s.Description,
+		Questions:   s.Questions,
+		ClosesAt:    formatTime(s.ClosesAt),
+	}
+}
+
+// SubmissionResponse represents the API response for a submission
+type SubmissionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	SurveyID    uuid.UUID `json:"surveyId"`
+	SubmittedAt string    `json:"submittedAt"`
+}
+
+func (s *Submission) ToResponse() SubmissionResponse {
+	return SubmissionResponse{
+		ID:          s.ID,
+		SurveyID:    s.SurveyID,
+		SubmittedAt: s.SubmittedAt.Format(time.RFC3339),
+	}
+}
+
+// QuestionResult aggregates the answers to a question
+type QuestionResult struct {
+	QuestionID    uuid.UUID        `json:"questionId"`
+	Prompt        string           `json:"prompt"`
+	Type          QuestionType     `json:"type"`
+	Answered      int64            `json:"answered"`
+	AverageRating *float64         `json:"averageRating,omitempty"`
+	Distribution  map[string]int64 `json:"distribution,omitempty"` // Rating value or option -> answers
+	TextAnswers   []string         `json:"textAnswers,omitempty"`  // Most recent text answers
+}
+
+// Results aggregates the submissions of a survey
+type Results struct {
+	SurveyID    uuid.UUID        `json:"surveyId"`
+	Title       string           `json:"title"`
+	Status      Status           `json:"status"`
+	Submissions int64            `json:"submissions"`
+	Questions   []QuestionResult `json:"questions"`
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
@@ -0,0 +1,258 @@
+package survey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, survey *Survey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Survey, error)
+	List(ctx context.Context, festivalID uuid.UUID, query ListSurveysQuery) ([]Survey, int64, error)
+	ListOpen(ctx context.Context, festivalID uuid.UUID, now time.Time) ([]Survey, error)
+	Update(ctx context.Context, survey *Survey) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Segments
+	IsInSegment(ctx context.Context, festivalID, userID uuid.UUID, segment Segment) (bool, error)
+
+	// Submissions
+	CreateSubmission(ctx context.Context, submission *Submission) error
+	HasSubmitted(ctx context.Context, surveyID, userID uuid.UUID) (bool, error)
+	ListSubmittedSurveyIDs(ctx context.Context, festivalID, userID uuid.UUID) ([]uuid.UUID, error)
+	CountSubmissionsByUserSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+
+	// Results
+	CountSubmissions(ctx context.Context, surveyID uuid.UUID) (int64, error)
+	GetAnsweredCounts(ctx context.Context, surveyID uuid.UUID) (map[uuid.UUID]int64, error)
+	GetAnswerCounts(ctx context.Context, surveyID uuid.UUID) ([]AnswerCount, error)
+	GetRecentTextAnswers(ctx context.Context, surveyID uuid.UUID, perQuestion int) ([]TextAnswer, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, survey *Survey) error {
+	return r.db.WithContext(ctx).Create(survey).Error
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Survey, error) {
+	var survey Survey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&survey).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get survey: %w", err)
+	}
+	return &survey, nil
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, query ListSurveysQuery) ([]Survey, int64, error) {
+	var surveys []Survey
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&Survey{}).Where("festival_id = ?", festivalID)
+	if query.Status != "" {
+		q = q.Where("status = ?", query.Status)
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count surveys: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	if err := q.Offset(offset).Limit(query.PerPage).Order("created_at DESC").Find(&surveys).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list surveys: %w", err)
+	}
+
+	return surveys, total, nil
+}
+
+// ListOpen lists the published surveys of a festival accepting responses at the given time
+func (r *repository) ListOpen(ctx context.Context, festivalID uuid.UUID, now time.Time) ([]Survey, error) {
+	var surveys []Survey
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND status = ?", festivalID, StatusPublished).
+		Where("(opens_at IS NULL OR opens_at <= ?) AND (closes_at IS NULL OR closes_at > ?)", now, now).
+		Order("published_at DESC").
+		Find(&surveys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open surveys: %w", err)
+	}
+	return surveys, nil
+}
+
+func (r *repository) Update(ctx context.Context, survey *Survey) error {
+	return r.db.WithContext(ctx).Save(survey).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&Survey{}, "id = ?", id).Error
+}
+
+// IsInSegment checks whether an attendee of the festival matches a survey segment
+func (r *repository) IsInSegment(ctx context.Context, festivalID, userID uuid.UUID, segment Segment) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM tickets t
+			WHERE t.festival_id = ? AND t.user_id = ?
+				AND t.status NOT IN ('CANCELLED', 'TRANSFERRED')`
+	args := []interface{}{festivalID, userID}
+
+	if len(segment.TicketTypeIDs) > 0 {
+		query += " AND t.ticket_type_id IN (?)"
+		args = append(args, segment.TicketTypeIDs)
+	}
+	if segment.CheckedInOnly {
+		query += " AND t.checked_in_at IS NOT NULL"
+	}
+	query += ")"
+
+	if segment.MinSpend > 0 {
+		query += `
+			AND (
+				SELECT COALESCE(SUM(o.total_amount), 0) FROM orders o
+				WHERE o.festival_id = ? AND o.user_id = ? AND o.status = 'PAID'
+			) >= ?`
+		args = append(args, festivalID, userID, segment.MinSpend)
+	}
+
+	var inSegment bool
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&inSegment).Error; err != nil {
+		return false, fmt.Errorf("failed to check survey segment: %w", err)
+	}
+	return inSegment, nil
+}
+
+// CreateSubmission stores a submission with its answers; a second submission of the
+// same attendee to a survey fails with ErrAlreadyResponded
+func (r *repository) CreateSubmission(ctx context.Context, submission *Submission) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(submission).Error
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrAlreadyResponded
+	}
+	return err
+}
+
+func (r *repository) HasSubmitted(ctx context.Context, surveyID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Submission{}).
+		Where("survey_id = ? AND user_id = ?", surveyID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check survey submission: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *repository) ListSubmittedSurveyIDs(ctx context.Context, festivalID, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&Submission{}).
+		Where("festival_id = ? AND user_id = ?", festivalID, userID).
+		Pluck("survey_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submitted surveys: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *repository) CountSubmissionsByUserSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Submission{}).
+		Where("user_id = ? AND submitted_at >= ?", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count survey submissions: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) CountSubmissions(ctx context.Context, surveyID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Submission{}).
+		Where("survey_id = ?", surveyID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count survey submissions: %w", err)
+	}
+	return count, nil
+}
+
+// GetAnsweredCounts returns the number of answers per question
+func (r *repository) GetAnsweredCounts(ctx context.Context, surveyID uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		QuestionID uuid.UUID
+		Count      int64
+	}
+	err := r.db.WithContext(ctx).Model(&Answer{}).
+		Select("question_id, COUNT(*) as count").
+		Where("survey_id = ?", surveyID).
+		Group("question_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count survey answers: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.QuestionID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetAnswerCounts counts the answers per rating value and per chosen option
+func (r *repository) GetAnswerCounts(ctx context.Context, surveyID uuid.UUID) ([]AnswerCount, error) {
+	var counts []AnswerCount
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT question_id, rating::text as value, COUNT(*) as count
+		FROM survey_answers
+		WHERE survey_id = ? AND rating IS NOT NULL
+		GROUP BY question_id, rating
+		UNION ALL
+		SELECT a.question_id, choice.value as value, COUNT(*) as count
+		FROM survey_answers a
+		CROSS JOIN LATERAL jsonb_array_elements_text(a.choices) AS choice(value)
+		WHERE a.survey_id = ? AND a.choices IS NOT NULL AND jsonb_typeof(a.choices) = 'array'
+		GROUP BY a.question_id, choice.value
+	`, surveyID, surveyID).Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey answer counts: %w", err)
+	}
+	return counts, nil
+}
+
+// GetRecentTextAnswers returns the most recent text answers of each question
+func (r *repository) GetRecentTextAnswers(ctx context.Context, surveyID uuid.UUID, perQuestion int) ([]TextAnswer, error) {
+	var answers []TextAnswer
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT question_id, text
+		FROM (
+			SELECT question_id, text,
+				ROW_NUMBER() OVER (PARTITION BY question_id ORDER BY created_at DESC) as rn
+			FROM survey_answers
+			WHERE survey_id = ? AND text <> ''
+		) ranked
+		WHERE rn <= ?
+		ORDER BY question_id, rn
+	`, surveyID, perQuestion).Scan(&answers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey text answers: %w", err)
+	}
+	return answers, nil
+}
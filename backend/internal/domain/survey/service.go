@@ -0,0 +1,316 @@
+package survey
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Create creates a draft survey
+func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, createdBy *uuid.UUID, req CreateSurveyRequest) (*Survey, error) {
+	questions, err := BuildQuestions(req.Questions)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(req.OpensAt, req.ClosesAt); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	survey := &Survey{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      StatusDraft,
+		Questions:   questions,
+		OpensAt:     req.OpensAt,
+		ClosesAt:    req.ClosesAt,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.Segment != nil {
+		survey.Segment = *req.Segment
+	}
+
+	if err := s.repo.Create(ctx, survey); err != nil {
+		return nil, err
+	}
+
+	return survey, nil
+}
+
+// Get returns a survey of the festival
+func (s *Service) Get(ctx context.Context, festivalID, id uuid.UUID) (*Survey, error) {
+	survey, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil || survey.FestivalID != festivalID {
+		return nil, ErrSurveyNotFound
+	}
+	return survey, nil
+}
+
+// List lists the surveys of a festival
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, query ListSurveysQuery) ([]Survey, int64, error) {
+	return s.repo.List(ctx, festivalID, query)
+}
+
+// Update updates a draft survey; replacing the questions assigns new question IDs
+func (s *Service) Update(ctx context.Context, festivalID, id uuid.UUID, req UpdateSurveyRequest) (*Survey, error) {
+	survey, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if survey.Status != StatusDraft {
+		return nil, ErrSurveyNotEditable
+	}
+
+	if req.Title != nil {
+		survey.Title = *req.Title
+	}
+	if req.Description != nil {
+		survey.Description = *req.Description
+	}
+	if req.Questions != nil {
+		questions, err := BuildQuestions(req.Questions)
+		if err != nil {
+			return nil, err
+		}
+		survey.Questions = questions
+	}
+	if req.Segment != nil {
+		survey.Segment = *req.Segment
+	}
+	if req.OpensAt != nil {
+		survey.OpensAt = req.OpensAt
+	}
+	if req.ClosesAt != nil {
+		survey.ClosesAt = req.ClosesAt
+	}
+	if err := validateSchedule(survey.OpensAt, survey.ClosesAt); err != nil {
+		return nil, err
+	}
+
+	survey.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, survey); err != nil {
+		return nil, err
+	}
+
+	return survey, nil
+}
+
+// Delete deletes a survey with its submissions
+func (s *Service) Delete(ctx context.Context, festivalID, id uuid.UUID) error {
+	if _, err := s.Get(ctx, festivalID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Publish makes a draft survey visible to its segment
+func (s *Service) Publish(ctx context.Context, festivalID, id uuid.UUID) (*Survey, error) {
+	survey, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if survey.Status != StatusDraft {
+		return nil, ErrSurveyNotDraft
+	}
+
+	now := time.Now()
+	if survey.ClosesAt != nil && !survey.ClosesAt.After(now) {
+		return nil, ErrInvalidSchedule
+	}
+
+	survey.Status = StatusPublished
+	survey.PublishedAt = &now
+	survey.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, survey); err != nil {
+		return nil, err
+	}
+
+	return survey, nil
+}
+
+// Close stops a survey from accepting responses
+func (s *Service) Close(ctx context.Context, festivalID, id uuid.UUID) (*Survey, error) {
+	survey, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if survey.Status == StatusClosed {
+		return survey, nil
+	}
+
+	now := time.Now()
+	survey.Status = StatusClosed
+	if survey.ClosesAt == nil || survey.ClosesAt.After(now) {
+		survey.ClosesAt = &now
+	}
+	survey.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, survey); err != nil {
+		return nil, err
+	}
+
+	return survey, nil
+}
+
+// ListAvailable lists the open surveys targeting the attendee that they have not answered yet
+func (s *Service) ListAvailable(ctx context.Context, festivalID, userID uuid.UUID) ([]Survey, error) {
+	surveys, err := s.repo.ListOpen(ctx, festivalID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(surveys) == 0 {
+		return surveys, nil
+	}
+
+	submitted, err := s.repo.ListSubmittedSurveyIDs(ctx, festivalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	answered := make(map[uuid.UUID]bool, len(submitted))
+	for _, id := range submitted {
+		answered[id] = true
+	}
+
+	available := make([]Survey, 0, len(surveys))
+	for _, survey := range surveys {
+		if answered[survey.ID] {
+			continue
+		}
+		inSegment, err := s.repo.IsInSegment(ctx, festivalID, userID, survey.Segment)
+		if err != nil {
+			return nil, err
+		}
+		if inSegment {
+			available = append(available, survey)
+		}
+	}
+
+	return available, nil
+}
+
+// GetForAttendee returns an open survey targeting the attendee
+func (s *Service) GetForAttendee(ctx context.Context, festivalID, userID, id uuid.UUID) (*Survey, error) {
+	survey, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if survey.Status == StatusDraft {
+		return nil, ErrSurveyNotFound
+	}
+	if !survey.IsOpen(time.Now()) {
+		return nil, ErrSurveyNotOpen
+	}
+
+	inSegment, err := s.repo.IsInSegment(ctx, festivalID, userID, survey.Segment)
+	if err != nil {
+		return nil, err
+	}
+	if !inSegment {
+		return nil, ErrNotInSegment
+	}
+
+	return survey, nil
+}
+
+// Submit records the answers of an attendee. Each attendee answers a survey once.
+func (s *Service) Submit(ctx context.Context, festivalID, userID, id uuid.UUID, req SubmitRequest) (*Submission, error) {
+	survey, err := s.GetForAttendee(ctx, festivalID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	submitted, err := s.repo.HasSubmitted(ctx, survey.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if submitted {
+		return nil, ErrAlreadyResponded
+	}
+
+	recent, err := s.repo.CountSubmissionsByUserSince(ctx, userID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if recent >= MaxSubmissionsPerHour {
+		return nil, ErrTooManySubmissions
+	}
+
+	answers, err := ValidateAnswers(survey.Questions, req.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	submission := &Submission{
+		ID:          uuid.New(),
+		SurveyID:    survey.ID,
+		FestivalID:  festivalID,
+		UserID:      userID,
+		SubmittedAt: now,
+	}
+	for i := range answers {
+		answers[i].ID = uuid.New()
+		answers[i].SubmissionID = submission.ID
+		answers[i].SurveyID = survey.ID
+		answers[i].CreatedAt = now
+	}
+	submission.Answers = answers
+
+	if err := s.repo.CreateSubmission(ctx, submission); err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// Results aggregates the submissions of a survey
+func (s *Service) Results(ctx context.Context, festivalID, id uuid.UUID) (*Results, error) {
+	survey, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.repo.CountSubmissions(ctx, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	answered, err := s.repo.GetAnsweredCounts(ctx, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.GetAnswerCounts(ctx, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	texts, err := s.repo.GetRecentTextAnswers(ctx, survey.ID, TextAnswersPerQuestion)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildResults(survey, submissions, answered, counts, texts), nil
+}
+
+func validateSchedule(opensAt, closesAt *time.Time) error {
+	if opensAt != nil && closesAt != nil && !closesAt.After(*opensAt) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package survey
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// BuildQuestions validates the question inputs of a survey and assigns their IDs
+func BuildQuestions(inputs []QuestionInput) ([]Question, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: a survey needs at least one question", ErrInvalidQuestions)
+	}
+	if len(inputs) > MaxQuestions {
+		return nil, fmt.Errorf("%w: a survey has at most %d questions", ErrInvalidQuestions, MaxQuestions)
+	}
+
+	questions := make([]Question, len(inputs))
+	for i, input := range inputs {
+		q := Question{
+			ID:       uuid.New(),
+			Type:     input.Type,
+			Prompt:   strings.TrimSpace(input.Prompt),
+			Required: input.Required,
+		}
+		if q.Prompt == "" {
+			return nil, fmt.Errorf("%w: question %d has no prompt", ErrInvalidQuestions, i+1)
+		}
+
+		switch input.Type {
+		case QuestionTypeRating:
+			q.Scale = input.Scale
+			if q.Scale == 0 {
+				q.Scale = DefaultRatingScale
+			}
+			if q.Scale < 2 || q.Scale > MaxRatingScale {
+				return nil, fmt.Errorf("%w: question %d scale must be between 2 and %d", ErrInvalidQuestions, i+1, MaxRatingScale)
+			}
+		case QuestionTypeMultipleChoice:
+			options, err := cleanOptions(input.Options)
+			if err != nil {
+				return nil, fmt.Errorf("%w: question %d %s", ErrInvalidQuestions, i+1, err.Error())
+			}
+			q.Options = options
+			q.MultipleAnswers = input.MultipleAnswers
+		case QuestionTypeText:
+		default:
+			return nil, fmt.Errorf("%w: question %d has unknown type %q", ErrInvalidQuestions, i+1, input.Type)
+		}
+
+		questions[i] = q
+	}
+
+	return questions, nil
+}
+
+func cleanOptions(options []string) ([]string, error) {
+	cleaned := make([]string, 0, len(options))
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, fmt.Errorf("has an empty option")
+		}
+		if seen[strings.ToLower(option)] {
+			return nil, fmt.Errorf("has duplicate option %q", option)
+		}
+		seen[strings.ToLower(option)] = true
+		cleaned = append(cleaned, option)
+	}
+	if len(cleaned) < 2 || len(cleaned) > MaxOptions {
+		return nil, fmt.Errorf("needs between 2 and %d options", MaxOptions)
+	}
+	return cleaned, nil
+}
+
+// ValidateAnswers checks a submission against the survey questions and returns the
+// answers to store. Unanswered optional questions are skipped.
+func ValidateAnswers(questions []Question, inputs []AnswerInput) ([]Answer, error) {
+	byID := make(map[uuid.UUID]AnswerInput, len(inputs))
+	for _, input := range inputs {
+		if _, dup := byID[input.QuestionID]; dup {
+			return nil, fmt.Errorf("%w: question %s answered twice", ErrInvalidAnswers, input.QuestionID)
+		}
+		byID[input.QuestionID] = input
+	}
+
+	answers := make([]Answer, 0, len(inputs))
+	for _, q := range questions {
+		input, ok := byID[q.ID]
+		delete(byID, q.ID)
+
+		answer := Answer{QuestionID: q.ID}
+		answered := false
+		if ok {
+			switch q.Type {
+			case QuestionTypeRating:
+				if input.Rating != nil {
+					if *input.Rating < 1 || *input.Rating > q.Scale {
+						return nil, fmt.Errorf("%w: rating for %q must be between 1 and %d", ErrInvalidAnswers, q.Prompt, q.Scale)
+					}
+					rating := *input.Rating
+					answer.Rating = &rating
+					answered = true
+				}
+			case QuestionTypeMultipleChoice:
+				choices, err := matchChoices(q, input.Choices)
+				if err != nil {
+					return nil, err
+				}
+				answer.Choices = choices
+				answered = len(choices) > 0
+			case QuestionTypeText:
+				text := strings.TrimSpace(input.Text)
+				if len(text) > MaxTextLength {
+					return nil, fmt.Errorf("%w: answer to %q is longer than %d characters", ErrInvalidAnswers, q.Prompt, MaxTextLength)
+				}
+				answer.Text = text
+				answered = text != ""
+			}
+		}
+
+		if !answered {
+			if q.Required {
+				return nil, fmt.Errorf("%w: %q is required", ErrInvalidAnswers, q.Prompt)
+			}
+			continue
+		}
+		answers = append(answers, answer)
+	}
+
+	for questionID := range byID {
+		return nil, fmt.Errorf("%w: unknown question %s", ErrInvalidAnswers, questionID)
+	}
+
+	return answers, nil
+}
+
+// matchChoices maps the submitted choices onto the question options, case-insensitively
+func matchChoices(q Question, choices []string) ([]string, error) {
+	if len(choices) > 1 && !q.MultipleAnswers {
+		return nil, fmt.Errorf("%w: %q accepts a single choice", ErrInvalidAnswers, q.Prompt)
+	}
+
+	matched := make([]string, 0, len(choices))
+	seen := make(map[string]bool, len(choices))
+	for _, choice := range choices {
+		option, ok := findOption(q.Options, choice)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an option of %q", ErrInvalidAnswers, choice, q.Prompt)
+		}
+		if !seen[option] {
+			seen[option] = true
+			matched = append(matched, option)
+		}
+	}
+	return matched, nil
+}
+
+func findOption(options []string, choice string) (string, bool) {
+	choice = strings.TrimSpace(choice)
+	for _, option := range options {
+		if strings.EqualFold(option, choice) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+// BuildResults aggregates the answer counts and text answers of a survey per question
+func BuildResults(survey *Survey, submissions int64, answered map[uuid.UUID]int64, counts []AnswerCount, texts []TextAnswer) *Results {
+	distributions := make(map[uuid.UUID]map[string]int64)
+	for _, c := range counts {
+		if distributions[c.QuestionID] == nil {
+			distributions[c.QuestionID] = make(map[string]int64)
+		}
+		distributions[c.QuestionID][c.Value] += c.Count
+	}
+
+	textsByQuestion := make(map[uuid.UUID][]string)
+	for _, t := range texts {
+		textsByQuestion[t.QuestionID] = append(textsByQuestion[t.QuestionID], t.Text)
+	}
+
+	results := &Results{
+		SurveyID:    survey.ID,
+		Title:       survey.Title,
+		Status:      survey.Status,
+		Submissions: submissions,
+		Questions:   make([]QuestionResult, len(survey.Questions)),
+	}
+
+	for i, q := range survey.Questions {
+		result := QuestionResult{
+			QuestionID: q.ID,
+			Prompt:     q.Prompt,
+			Type:       q.Type,
+			Answered:   answered[q.ID],
+		}
+
+		switch q.Type {
+		case QuestionTypeRating:
+			result.Distribution = make(map[string]int64, q.Scale)
+			for v := 1; v <= q.Scale; v++ {
+				result.Distribution[strconv.Itoa(v)] = 0
+			}
+			var sum, total int64
+			for value, count := range distributions[q.ID] {
+				rating, err := strconv.Atoi(value)
+				if err != nil {
+					continue
+				}
+				result.Distribution[value] = count
+				sum += int64(rating) * count
+				total += count
+			}
+			if total > 0 {
+				avg := math.Round(float64(sum)/float64(total)*100) / 100
+				result.AverageRating = &avg
+			}
+		case QuestionTypeMultipleChoice:
+			result.Distribution = make(map[string]int64, len(q.Options))
+			for _, option := range q.Options {
+				result.Distribution[option] = distributions[q.ID][option]
+			}
+		case QuestionTypeText:
+			result.TextAnswers = textsByQuestion[q.ID]
+		}
+
+		results.Questions[i] = result
+	}
+
+	return results
+}
@@ -0,0 +1,132 @@
+package survey
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func testQuestions(t *testing.T) []Question {
+	questions, err := BuildQuestions([]QuestionInput{
+		{Type: QuestionTypeRating, Prompt: "How was the festival?", Required: true},
+		{Type: QuestionTypeMultipleChoice, Prompt: "Favourite stage?", Options: []string{"Main", " Tent ", "Club"}},
+		{Type: QuestionTypeMultipleChoice, Prompt: "What did you eat?", Options: []string{"Burgers", "Fries", "Pizza"}, MultipleAnswers: true},
+		{Type: QuestionTypeText, Prompt: "Anything else?"},
+	})
+	require.NoError(t, err)
+	return questions
+}
+
+// TestBuildQuestions tests question validation when building a survey
+func TestBuildQuestions(t *testing.T) {
+	t.Run("assigns IDs and defaults", func(t *testing.T) {
+		questions := testQuestions(t)
+		require.Len(t, questions, 4)
+		assert.NotEqual(t, uuid.Nil, questions[0].ID)
+		assert.Equal(t, DefaultRatingScale, questions[0].Scale)
+		assert.Equal(t, []string{"Main", "Tent", "Club"}, questions[1].Options)
+		assert.Empty(t, questions[3].Options)
+	})
+
+	tests := []struct {
+		name   string
+		inputs []QuestionInput
+	}{
+		{name: "no questions", inputs: nil},
+		{name: "blank prompt", inputs: []QuestionInput{{Type: QuestionTypeText, Prompt: "  "}}},
+		{name: "rating scale too large", inputs: []QuestionInput{{Type: QuestionTypeRating, Prompt: "Rate", Scale: 11}}},
+		{name: "single option", inputs: []QuestionInput{{Type: QuestionTypeMultipleChoice, Prompt: "Pick", Options: []string{"A"}}}},
+		{name: "duplicate options", inputs: []QuestionInput{{Type: QuestionTypeMultipleChoice, Prompt: "Pick", Options: []string{"A", "a"}}}},
+		{name: "unknown type", inputs: []QuestionInput{{Type: "DATE", Prompt: "When?"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildQuestions(tt.inputs)
+			assert.ErrorIs(t, err, ErrInvalidQuestions)
+		})
+	}
+}
+
+// TestValidateAnswers tests submission validation against the survey questions
+func TestValidateAnswers(t *testing.T) {
+	questions := testQuestions(t)
+	rating, stage, food, text := questions[0].ID, questions[1].ID, questions[2].ID, questions[3].ID
+
+	t.Run("valid submission skips unanswered optional questions", func(t *testing.T) {
+		answers, err := ValidateAnswers(questions, []AnswerInput{
+			{QuestionID: rating, Rating: intPtr(4)},
+			{QuestionID: stage, Choices: []string{"tent"}},
+			{QuestionID: text, Text: "  Great vibes  "},
+		})
+		require.NoError(t, err)
+		require.Len(t, answers, 3)
+		assert.Equal(t, 4, *answers[0].Rating)
+		assert.Equal(t, []string{"Tent"}, answers[1].Choices)
+		assert.Equal(t, "Great vibes", answers[2].Text)
+	})
+
+	t.Run("multiple answers are deduplicated", func(t *testing.T) {
+		answers, err := ValidateAnswers(questions, []AnswerInput{
+			{QuestionID: rating, Rating: intPtr(5)},
+			{QuestionID: food, Choices: []string{"Fries", "Pizza", "fries"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Fries", "Pizza"}, answers[1].Choices)
+	})
+
+	tests := []struct {
+		name    string
+		answers []AnswerInput
+	}{
+		{name: "missing required answer", answers: []AnswerInput{{QuestionID: text, Text: "Hi"}}},
+		{name: "rating out of scale", answers: []AnswerInput{{QuestionID: rating, Rating: intPtr(6)}}},
+		{name: "unknown option", answers: []AnswerInput{{QuestionID: rating, Rating: intPtr(3)}, {QuestionID: stage, Choices: []string{"Beach"}}}},
+		{name: "several choices on single answer question", answers: []AnswerInput{{QuestionID: rating, Rating: intPtr(3)}, {QuestionID: stage, Choices: []string{"Main", "Club"}}}},
+		{name: "question answered twice", answers: []AnswerInput{{QuestionID: rating, Rating: intPtr(3)}, {QuestionID: rating, Rating: intPtr(4)}}},
+		{name: "unknown question", answers: []AnswerInput{{QuestionID: rating, Rating: intPtr(3)}, {QuestionID: uuid.New(), Text: "?"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateAnswers(questions, tt.answers)
+			assert.ErrorIs(t, err, ErrInvalidAnswers)
+		})
+	}
+}
+
+// TestBuildResults tests the aggregation of answers per question
+func TestBuildResults(t *testing.T) {
+	questions := testQuestions(t)
+	survey := &Survey{ID: uuid.New(), Title: "Post-festival", Status: StatusClosed, Questions: questions}
+	rating, stage, text := questions[0].ID, questions[1].ID, questions[3].ID
+
+	results := BuildResults(survey, 3,
+		map[uuid.UUID]int64{rating: 3, stage: 2, text: 1},
+		[]AnswerCount{
+			{QuestionID: rating, Value: "5", Count: 2},
+			{QuestionID: rating, Value: "2", Count: 1},
+			{QuestionID: stage, Value: "Main", Count: 2},
+		},
+		[]TextAnswer{{QuestionID: text, Text: "More toilets"}},
+	)
+
+	require.Len(t, results.Questions, 4)
+	assert.Equal(t, int64(3), results.Submissions)
+
+	ratingResult := results.Questions[0]
+	require.NotNil(t, ratingResult.AverageRating)
+	assert.Equal(t, 4.0, *ratingResult.AverageRating)
+	assert.Len(t, ratingResult.Distribution, 5)
+	assert.Equal(t, int64(0), ratingResult.Distribution["1"])
+
+	assert.Equal(t, map[string]int64{"Main": 2, "Tent": 0, "Club": 0}, results.Questions[1].Distribution)
+	assert.Nil(t, results.Questions[2].AverageRating)
+	assert.Equal(t, []string{"More toilets"}, results.Questions[3].TextAnswers)
+}
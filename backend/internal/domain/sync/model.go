@@ -181,3 +181,21 @@ type PendingBatchResponse struct {
 	TotalCount int        `json:"totalCount"`
 	CreatedAt  string     `json:"createdAt"`
 }
+
+// ResyncResult lists the batches of a device processed again by a resync, or that a dry
+// run would process
+type ResyncResult struct {
+	DeviceID string          `json:"deviceId"`
+	DryRun   bool            `json:"dryRun"`
+	Batches  []ResyncedBatch `json:"batches"`
+}
+
+// ResyncedBatch is a batch of a resync with its transactions still to process
+type ResyncedBatch struct {
+	BatchID     uuid.UUID   `json:"batchId"`
+	Status      SyncStatus  `json:"status"` // Before the resync
+	TotalCount  int         `json:"totalCount"`
+	Unprocessed int         `json:"unprocessed"` // Not processed yet or failed
+	CreatedAt   time.Time   `json:"createdAt"`
+	Result      *SyncResult `json:"result,omitempty"` // Absent on a dry run
+}
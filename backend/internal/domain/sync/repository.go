@@ -16,6 +16,7 @@ type Repository interface {
 	UpdateBatchStatus(ctx context.Context, id uuid.UUID, status SyncStatus, result *SyncResultData) error
 	GetPendingBatches(ctx context.Context, festivalID uuid.UUID) ([]SyncBatch, error)
 	GetPendingBatchesByDevice(ctx context.Context, deviceID string) ([]SyncBatch, error)
+	GetUnfinishedBatchesByDevice(ctx context.Context, festivalID uuid.UUID, deviceID string) ([]SyncBatch, error)
 
 	// Transaction tracking
 	MarkTransactionProcessed(ctx context.Context, batchID uuid.UUID, localID string, serverTxID uuid.UUID) error
@@ -93,6 +94,21 @@ func (r *repository) GetPendingBatchesByDevice(ctx context.Context, deviceID str
 	return batches, nil
 }
 
+// GetUnfinishedBatchesByDevice returns the batches of a device not completed: pending,
+// stuck processing, failed or partly failed
+func (r *repository) GetUnfinishedBatchesByDevice(ctx context.Context, festivalID uuid.UUID, deviceID string) ([]SyncBatch, error) {
+	var batches []SyncBatch
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND device_id = ? AND status IN ?", festivalID, deviceID,
+			[]SyncStatus{SyncStatusPending, SyncStatusProcessing, SyncStatusFailed, SyncStatusPartial}).
+		Order("created_at ASC").
+		Find(&batches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unfinished batches for device: %w", err)
+	}
+	return batches, nil
+}
+
 func (r *repository) MarkTransactionProcessed(ctx context.Context, batchID uuid.UUID, localID string, serverTxID uuid.UUID) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var batch SyncBatch
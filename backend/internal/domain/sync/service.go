@@ -11,29 +11,43 @@ import (
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 )
 
 type Service struct {
 	repo          Repository
 	walletRepo    wallet.Repository
-	secretKey     []byte
+	secrets       *security.Keyring
 	maxBatchAge   time.Duration // Maximum age for offline transactions
+	sequencer     wallet.ChangeSequencer
 }
 
 func NewService(repo Repository, walletRepo wallet.Repository, secretKey string) *Service {
 	return &Service{
 		repo:        repo,
 		walletRepo:  walletRepo,
-		secretKey:   []byte(secretKey),
+		secrets:     security.NewKeyring(secretKey, nil, 0),
 		maxBatchAge: 24 * time.Hour, // Default: 24 hours
 	}
 }
 
+// SetKeyring replaces the secret given to the constructor with a keyring that can be
+// rotated at runtime
+func (s *Service) SetKeyring(keyring *security.Keyring) {
+	s.secrets = keyring
+}
+
 // SetMaxBatchAge sets the maximum age for offline transactions
 func (s *Service) SetMaxBatchAge(duration time.Duration) {
 	s.maxBatchAge = duration
 }
 
+// SetChangeSequencer counts the balance changes of the offline transactions applied
+// for the POS terminals
+func (s *Service) SetChangeSequencer(sequencer wallet.ChangeSequencer) {
+	s.sequencer = sequencer
+}
+
 // ProcessSyncBatch processes a batch of offline transactions
 func (s *Service) ProcessSyncBatch(ctx context.Context, req SubmitBatchRequest) (*SyncResult, error) {
 	// Create the batch
@@ -50,6 +64,13 @@ func (s *Service) ProcessSyncBatch(ctx context.Context, req SubmitBatchRequest)
 		return nil, fmt.Errorf("failed to create batch: %w", err)
 	}
 
+	return s.processBatch(ctx, batch)
+}
+
+// processBatch processes the transactions of a stored batch and records the result.
+// Transactions already processed are recognized as duplicates, so a batch can be
+// processed again.
+func (s *Service) processBatch(ctx context.Context, batch *SyncBatch) (*SyncResult, error) {
 	// Update status to processing
 	if err := s.repo.UpdateBatchStatus(ctx, batch.ID, SyncStatusProcessing, nil); err != nil {
 		return nil, fmt.Errorf("failed to update batch status: %w", err)
@@ -58,12 +79,12 @@ func (s *Service) ProcessSyncBatch(ctx context.Context, req SubmitBatchRequest)
 	// Process each transaction
 	result := &SyncResult{
 		BatchID:    batch.ID,
-		TotalCount: len(req.Transactions),
+		TotalCount: len(batch.Transactions),
 		Conflicts:  []SyncConflict{},
 		Successes:  []SyncSuccess{},
 	}
 
-	for _, tx := range req.Transactions {
+	for _, tx := range batch.Transactions {
 		// Validate the offline signature
 		if err := s.ValidateOfflineSignature(tx); err != nil {
 			result.FailedCount++
@@ -77,7 +98,7 @@ func (s *Service) ProcessSyncBatch(ctx context.Context, req SubmitBatchRequest)
 		}
 
 		// Check for duplicate transactions
-		isDupe, existingTxID := s.DetectDuplicates(ctx, tx, req.DeviceID)
+		isDupe, existingTxID := s.DetectDuplicates(ctx, tx, batch.DeviceID)
 		if isDupe {
 			result.SuccessCount++ // Count as success since it was already processed
 			result.Successes = append(result.Successes, SyncSuccess{
@@ -127,6 +148,9 @@ func (s *Service) ProcessSyncBatch(ctx context.Context, req SubmitBatchRequest)
 				}
 				conflict.Resolution = "insufficient_balance_unresolved"
 			}
+			if errors.Is(err, wallet.ErrWalletFrozen) {
+				conflict.Resolution = "rejected"
+			}
 
 			result.Conflicts = append(result.Conflicts, conflict)
 			_ = s.repo.MarkTransactionFailed(ctx, batch.ID, tx.LocalID, err.Error())
@@ -167,18 +191,75 @@ func (s *Service) ProcessSyncBatch(ctx context.Context, req SubmitBatchRequest)
 	return result, nil
 }
 
+// ResyncDevice processes again the batches of a device that did not go through, e.g.
+// stuck in PROCESSING after a restart or failed while a wallet was frozen. A dry run
+// only lists the batches and their transactions still to process.
+func (s *Service) ResyncDevice(ctx context.Context, festivalID uuid.UUID, deviceID string, dryRun bool) (*ResyncResult, error) {
+	batches, err := s.repo.GetUnfinishedBatchesByDevice(ctx, festivalID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResyncResult{DeviceID: deviceID, DryRun: dryRun, Batches: []ResyncedBatch{}}
+	for i := range batches {
+		batch := &batches[i]
+		resynced := ResyncedBatch{
+			BatchID:    batch.ID,
+			Status:     batch.Status,
+			TotalCount: len(batch.Transactions),
+			CreatedAt:  batch.CreatedAt,
+		}
+		for _, tx := range batch.Transactions {
+			if !tx.Processed || tx.Error != "" {
+				resynced.Unprocessed++
+			}
+		}
+
+		if !dryRun {
+			processed, err := s.processBatch(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
+			resynced.Result = processed
+		}
+		result.Batches = append(result.Batches, resynced)
+	}
+
+	return result, nil
+}
+
+// checkWalletUsable refuses the offline credits of frozen and closed wallets, as the
+// wallet repository does for payments
+func checkWalletUsable(w *wallet.Wallet) error {
+	switch w.Status {
+	case wallet.WalletStatusActive:
+		return nil
+	case wallet.WalletStatusFrozen:
+		return wallet.ErrWalletFrozen
+	default:
+		return fmt.Errorf("wallet is not active")
+	}
+}
+
 // processTransaction processes a single offline transaction
 func (s *Service) processTransaction(ctx context.Context, tx OfflineTransaction) (uuid.UUID, error) {
+	var serverTxID uuid.UUID
+	var err error
 	switch tx.Type {
 	case TransactionTypePurchase:
-		return s.processPurchase(ctx, tx)
+		serverTxID, err = s.processPurchase(ctx, tx)
 	case TransactionTypeRefund:
-		return s.processRefund(ctx, tx)
+		serverTxID, err = s.processRefund(ctx, tx)
 	case TransactionTypeTopUp, TransactionTypeCashIn:
-		return s.processTopUp(ctx, tx)
+		serverTxID, err = s.processTopUp(ctx, tx)
 	default:
 		return uuid.Nil, fmt.Errorf("unknown transaction type: %s", tx.Type)
 	}
+
+	if err == nil && s.sequencer != nil {
+		s.sequencer.WalletChanged(ctx, tx.WalletID)
+	}
+	return serverTxID, err
 }
 
 func (s *Service) processPurchase(ctx context.Context, tx OfflineTransaction) (uuid.UUID, error) {
@@ -215,6 +296,9 @@ func (s *Service) processRefund(ctx context.Context, tx OfflineTransaction) (uui
 	if w == nil {
 		return uuid.Nil, errors.ErrWalletNotFound
 	}
+	if err := checkWalletUsable(w); err != nil {
+		return uuid.Nil, err
+	}
 
 	// Create refund transaction
 	refundTx := &wallet.Transaction{
@@ -258,6 +342,9 @@ func (s *Service) processTopUp(ctx context.Context, tx OfflineTransaction) (uuid
 	if w == nil {
 		return uuid.Nil, errors.ErrWalletNotFound
 	}
+	if err := checkWalletUsable(w); err != nil {
+		return uuid.Nil, err
+	}
 
 	// Determine transaction type
 	txType := wallet.TransactionTypeTopUp
@@ -303,11 +390,11 @@ func (s *Service) ValidateOfflineSignature(tx OfflineTransaction) error {
 		return fmt.Errorf("missing signature")
 	}
 
-	// Recreate the expected signature
-	expectedSig := s.generateSignature(tx)
-
-	// Compare signatures
-	if !hmac.Equal([]byte(tx.Signature), []byte(expectedSig)) {
+	// Devices may have signed with the previous secret while offline during a rotation
+	valid := s.secrets.Verify(tx.Signature, func(secret []byte) string {
+		return generateSignatureWith(secret, tx)
+	})
+	if !valid {
 		return fmt.Errorf("signature mismatch")
 	}
 
@@ -316,6 +403,10 @@ func (s *Service) ValidateOfflineSignature(tx OfflineTransaction) error {
 
 // generateSignature generates the expected signature for an offline transaction
 func (s *Service) generateSignature(tx OfflineTransaction) string {
+	return generateSignatureWith(s.secrets.Current(), tx)
+}
+
+func generateSignatureWith(secret []byte, tx OfflineTransaction) string {
 	// Create deterministic data string
 	data := fmt.Sprintf("%s:%s:%d:%s:%d",
 		tx.LocalID,
@@ -325,7 +416,7 @@ func (s *Service) generateSignature(tx OfflineTransaction) string {
 		tx.Timestamp.Unix(),
 	)
 
-	h := hmac.New(sha256.New, s.secretKey)
+	h := hmac.New(sha256.New, secret)
 	h.Write([]byte(data))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
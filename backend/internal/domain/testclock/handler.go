@@ -0,0 +1,186 @@
+package testclock
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped test clock routes, which should never be
+// registered in production
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	testClock := r.Group("/test-clock")
+	{
+		testClock.GET("", h.Get)
+		testClock.POST("", h.Create)
+		testClock.POST("/advance", h.Advance)
+		testClock.DELETE("", h.Delete)
+	}
+}
+
+// Get gets the test clock of a sandbox festival
+// @Summary Get test clock
+// @Description Get the virtual time the scheduled jobs of a sandbox festival run at
+// @Tags test-clocks
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=TestClock} "Test clock"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No test clock"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/test-clock [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	testClock, err := h.service.Get(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, testClock)
+}
+
+// Create attaches a test clock to a sandbox festival
+// @Summary Create test clock
+// @Description Run the scheduled jobs of a sandbox festival (price list activation, auto-cancel of unpaid orders, scheduled wallet unfreezes) at a virtual time, frozen until advanced
+// @Tags test-clocks
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateTestClockRequest false "Initial virtual time, now by default"
+// @Success 201 {object} response.Response{data=TestClock} "Test clock created"
+// @Failure 400 {object} response.ErrorResponse "Not a sandbox festival"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Failure 409 {object} response.ErrorResponse "Festival already has a test clock"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/test-clock [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateTestClockRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	testClock, err := h.service.Create(c.Request.Context(), festivalID, req, currentUser(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, testClock)
+}
+
+// Advance moves the test clock of a sandbox festival forward
+// @Summary Advance test clock
+// @Description Move the virtual time of a sandbox festival forward, to a time or by some minutes, and run its scheduled jobs at the new time
+// @Tags test-clocks
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body AdvanceTestClockRequest true "New virtual time or minutes to advance by"
+// @Success 200 {object} response.Response{data=TestClock} "Test clock advanced"
+// @Failure 400 {object} response.ErrorResponse "Invalid advance"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No test clock"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/test-clock/advance [post]
+func (h *Handler) Advance(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req AdvanceTestClockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	testClock, err := h.service.Advance(c.Request.Context(), festivalID, req, currentUser(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, testClock)
+}
+
+// Delete detaches the test clock of a sandbox festival
+// @Summary Delete test clock
+// @Description Put a sandbox festival back on the wall time
+// @Tags test-clocks
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 204 "Test clock deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "No test clock"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/test-clock [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), festivalID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func currentUser(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrClockNotFound):
+		response.NotFound(c, "Festival has no test clock")
+	case errors.Is(err, ErrNotSandbox):
+		response.BadRequest(c, "NOT_SANDBOX", err.Error(), nil)
+	case errors.Is(err, ErrClockExists):
+		response.Conflict(c, "TEST_CLOCK_EXISTS", err.Error())
+	case errors.Is(err, ErrMovesForward):
+		response.BadRequest(c, "TEST_CLOCK_BACKWARDS", err.Error(), nil)
+	case errors.Is(err, ErrAdvanceConflict):
+		response.BadRequest(c, "ADVANCE_CONFLICT", err.Error(), nil)
+	case errors.Is(err, ErrAdvanceTooFar):
+		response.BadRequest(c, "ADVANCE_TOO_FAR", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,49 @@
+package testclock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test clock errors
+var (
+	ErrFestivalNotFound = errors.New("festival not found")
+	ErrNotSandbox       = errors.New("test clocks are only available for sandbox festivals")
+	ErrClockExists      = errors.New("festival already has a test clock")
+	ErrClockNotFound    = errors.New("festival has no test clock")
+	ErrMovesForward     = errors.New("a test clock only moves forward")
+	ErrAdvanceConflict  = errors.New("give either to or minutes, not both")
+	ErrAdvanceTooFar    = errors.New("a test clock advances by at most 90 days at a time")
+)
+
+// MaxAdvance bounds a single advance of a test clock, so that a typo does not jump
+// a festival years ahead
+const MaxAdvance = 90 * 24 * time.Hour
+
+// TestClock is the virtual time of a sandbox festival. The festival's scheduled jobs
+// run at this time instead of the wall time, and it stays frozen until advanced.
+type TestClock struct {
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;primary_key"`
+	FrozenTime time.Time  `json:"frozenTime" gorm:"not null"`
+	CreatedBy  *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	AdvancedBy *uuid.UUID `json:"advancedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (TestClock) TableName() string {
+	return "test_clocks"
+}
+
+// CreateTestClockRequest attaches a test clock to a sandbox festival
+type CreateTestClockRequest struct {
+	FrozenTime *time.Time `json:"frozenTime"` // The current time by default
+}
+
+// AdvanceTestClockRequest moves a test clock forward, to a time or by some minutes
+type AdvanceTestClockRequest struct {
+	To      *time.Time `json:"to"`
+	Minutes int        `json:"minutes" binding:"omitempty,min=1"`
+}
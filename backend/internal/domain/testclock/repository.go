@@ -0,0 +1,87 @@
+package testclock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	IsSandbox(ctx context.Context, festivalID uuid.UUID) (*bool, error)
+	Create(ctx context.Context, clock *TestClock) error
+	GetByFestival(ctx context.Context, festivalID uuid.UUID) (*TestClock, error)
+	Update(ctx context.Context, clock *TestClock) error
+	Delete(ctx context.Context, festivalID uuid.UUID) error
+	ListAll(ctx context.Context) ([]TestClock, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// IsSandbox tells whether a festival is a sandbox festival, nil if it does not exist
+func (r *repository) IsSandbox(ctx context.Context, festivalID uuid.UUID) (*bool, error) {
+	var sandbox []bool
+	err := r.db.WithContext(ctx).
+		Table("public.festivals").
+		Select("sandbox").
+		Where("id = ?", festivalID).
+		Limit(1).
+		Scan(&sandbox).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(sandbox) == 0 {
+		return nil, nil
+	}
+	return &sandbox[0], nil
+}
+
+func (r *repository) Create(ctx context.Context, clock *TestClock) error {
+	if err := r.db.WithContext(ctx).Create(clock).Error; err != nil {
+		return fmt.Errorf("failed to create test clock: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetByFestival(ctx context.Context, festivalID uuid.UUID) (*TestClock, error) {
+	var clock TestClock
+	err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).First(&clock).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get test clock: %w", err)
+	}
+	return &clock, nil
+}
+
+func (r *repository) Update(ctx context.Context, clock *TestClock) error {
+	if err := r.db.WithContext(ctx).Save(clock).Error; err != nil {
+		return fmt.Errorf("failed to update test clock: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, festivalID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).Delete(&TestClock{}).Error; err != nil {
+		return fmt.Errorf("failed to delete test clock: %w", err)
+	}
+	return nil
+}
+
+// ListAll lists the test clocks of every festival, read by the scheduled jobs
+func (r *repository) ListAll(ctx context.Context) ([]TestClock, error) {
+	var clocks []TestClock
+	if err := r.db.WithContext(ctx).Find(&clocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list test clocks: %w", err)
+	}
+	return clocks, nil
+}
@@ -0,0 +1,56 @@
+package testclock
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) IsSandbox(ctx context.Context, festivalID uuid.UUID) (*bool, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*bool), args.Error(1)
+}
+
+func (m *MockRepository) Create(ctx context.Context, clock *TestClock) error {
+	args := m.Called(ctx, clock)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetByFestival(ctx context.Context, festivalID uuid.UUID) (*TestClock, error) {
+	args := m.Called(ctx, festivalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TestClock), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, clock *TestClock) error {
+	args := m.Called(ctx, clock)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, festivalID uuid.UUID) error {
+	args := m.Called(ctx, festivalID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListAll(ctx context.Context) ([]TestClock, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]TestClock), args.Error(1)
+}
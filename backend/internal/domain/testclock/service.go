@@ -0,0 +1,181 @@
+package testclock
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/rs/zerolog/log"
+)
+
+// Service manages the test clocks of sandbox festivals and tells the time of festivals
+// to the scheduled jobs, as a clock.Clock. Clocks are read from the database on every
+// call, so that the worker sees an advance made through the API on its next run.
+type Service struct {
+	repo        Repository
+	queueClient *queue.Client
+	taskTypes   []string // Scheduled tasks run again after an advance
+	now         func() time.Time
+}
+
+var _ clock.Clock = (*Service)(nil)
+
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// SetScheduler enqueues the scheduled tasks given when a test clock is advanced, so
+// that their jobs catch up with the new time without waiting for their next run
+func (s *Service) SetScheduler(queueClient *queue.Client, taskTypes ...string) {
+	s.queueClient = queueClient
+	s.taskTypes = taskTypes
+}
+
+// Now returns the virtual time of a festival on a test clock, the wall time otherwise
+func (s *Service) Now(ctx context.Context, festivalID uuid.UUID) time.Time {
+	testClock, err := s.repo.GetByFestival(ctx, festivalID)
+	if err != nil {
+		log.Warn().Err(err).Str("festival_id", festivalID.String()).Msg("Failed to get test clock, using wall time")
+		return s.now()
+	}
+	if testClock == nil {
+		return s.now()
+	}
+	return testClock.FrozenTime
+}
+
+// Virtual returns the festivals on a test clock with their virtual time
+func (s *Service) Virtual(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+	clocks, err := s.repo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	virtual := make(map[uuid.UUID]time.Time, len(clocks))
+	for _, testClock := range clocks {
+		virtual[testClock.FestivalID] = testClock.FrozenTime
+	}
+	return virtual, nil
+}
+
+// Get gets the test clock of a festival
+func (s *Service) Get(ctx context.Context, festivalID uuid.UUID) (*TestClock, error) {
+	testClock, err := s.repo.GetByFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if testClock == nil {
+		return nil, ErrClockNotFound
+	}
+	return testClock, nil
+}
+
+// Create attaches a test clock to a sandbox festival, frozen at the time requested
+func (s *Service) Create(ctx context.Context, festivalID uuid.UUID, req CreateTestClockRequest, createdBy *uuid.UUID) (*TestClock, error) {
+	if err := s.checkSandbox(ctx, festivalID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByFestival(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrClockExists
+	}
+
+	now := s.now()
+	frozenTime := now
+	if req.FrozenTime != nil {
+		frozenTime = *req.FrozenTime
+	}
+
+	testClock := &TestClock{
+		FestivalID: festivalID,
+		FrozenTime: frozenTime.UTC(),
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.Create(ctx, testClock); err != nil {
+		return nil, err
+	}
+	return testClock, nil
+}
+
+// Advance moves the test clock of a festival forward, to a time or by some minutes,
+// and runs the scheduled jobs again so that what was due in between happens now
+func (s *Service) Advance(ctx context.Context, festivalID uuid.UUID, req AdvanceTestClockRequest, advancedBy *uuid.UUID) (*TestClock, error) {
+	if req.To != nil && req.Minutes > 0 {
+		return nil, ErrAdvanceConflict
+	}
+
+	testClock, err := s.Get(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := testClock.FrozenTime.Add(time.Duration(req.Minutes) * time.Minute)
+	if req.To != nil {
+		to = req.To.UTC()
+	}
+	if !to.After(testClock.FrozenTime) {
+		return nil, ErrMovesForward
+	}
+	if to.Sub(testClock.FrozenTime) > MaxAdvance {
+		return nil, ErrAdvanceTooFar
+	}
+
+	testClock.FrozenTime = to
+	testClock.AdvancedBy = advancedBy
+	testClock.UpdatedAt = s.now()
+	if err := s.repo.Update(ctx, testClock); err != nil {
+		return nil, err
+	}
+
+	s.runScheduledTasks(ctx, festivalID)
+	return testClock, nil
+}
+
+// Delete detaches the test clock of a festival, which runs on the wall time again
+func (s *Service) Delete(ctx context.Context, festivalID uuid.UUID) error {
+	if _, err := s.Get(ctx, festivalID); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, festivalID)
+}
+
+func (s *Service) checkSandbox(ctx context.Context, festivalID uuid.UUID) error {
+	sandbox, err := s.repo.IsSandbox(ctx, festivalID)
+	if err != nil {
+		return err
+	}
+	if sandbox == nil {
+		return ErrFestivalNotFound
+	}
+	if !*sandbox {
+		return ErrNotSandbox
+	}
+	return nil
+}
+
+// runScheduledTasks enqueues the scheduled tasks after an advance. A task that fails to
+// enqueue still runs on its schedule, so it does not fail the advance.
+func (s *Service) runScheduledTasks(ctx context.Context, festivalID uuid.UUID) {
+	if s.queueClient == nil {
+		return
+	}
+	for _, taskType := range s.taskTypes {
+		if _, err := s.queueClient.EnqueueTask(ctx, asynq.NewTask(taskType, nil)); err != nil {
+			log.Warn().Err(err).
+				Str("festival_id", festivalID.String()).
+				Str("task", taskType).
+				Msg("Failed to run scheduled task after test clock advance")
+		}
+	}
+}
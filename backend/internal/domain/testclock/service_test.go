@@ -0,0 +1,96 @@
+package testclock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestService_Create(t *testing.T) {
+	sandbox := uuid.New()
+	live := uuid.New()
+	missing := uuid.New()
+	now := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("IsSandbox", mock.Anything, sandbox).Return(boolPtr(true), nil)
+	mockRepo.On("IsSandbox", mock.Anything, live).Return(boolPtr(false), nil)
+	mockRepo.On("IsSandbox", mock.Anything, missing).Return(nil, nil)
+	mockRepo.On("GetByFestival", mock.Anything, sandbox).Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*testclock.TestClock")).Return(nil)
+
+	service := NewService(mockRepo)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	testClock, err := service.Create(ctx, sandbox, CreateTestClockRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, now, testClock.FrozenTime, "frozen at the current time by default")
+
+	_, err = service.Create(ctx, live, CreateTestClockRequest{}, nil)
+	assert.ErrorIs(t, err, ErrNotSandbox)
+
+	_, err = service.Create(ctx, missing, CreateTestClockRequest{}, nil)
+	assert.ErrorIs(t, err, ErrFestivalNotFound)
+}
+
+func TestService_Advance(t *testing.T) {
+	festivalID := uuid.New()
+	frozen := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+	staffID := uuid.New()
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetByFestival", mock.Anything, festivalID).Return(&TestClock{FestivalID: festivalID, FrozenTime: frozen}, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*testclock.TestClock")).Return(nil)
+
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	testClock, err := service.Advance(ctx, festivalID, AdvanceTestClockRequest{Minutes: 90}, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, frozen.Add(90*time.Minute), testClock.FrozenTime)
+	assert.Equal(t, &staffID, testClock.AdvancedBy)
+
+	earlier := testClock.FrozenTime.Add(-time.Hour)
+	_, err = service.Advance(ctx, festivalID, AdvanceTestClockRequest{To: &earlier}, nil)
+	assert.ErrorIs(t, err, ErrMovesForward)
+
+	later := testClock.FrozenTime.Add(MaxAdvance + time.Minute)
+	_, err = service.Advance(ctx, festivalID, AdvanceTestClockRequest{To: &later}, nil)
+	assert.ErrorIs(t, err, ErrAdvanceTooFar)
+
+	_, err = service.Advance(ctx, festivalID, AdvanceTestClockRequest{To: &later, Minutes: 5}, nil)
+	assert.ErrorIs(t, err, ErrAdvanceConflict)
+}
+
+func TestService_Clock(t *testing.T) {
+	sandbox := uuid.New()
+	other := uuid.New()
+	frozen := time.Date(2026, 7, 20, 23, 30, 0, 0, time.UTC)
+	now := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetByFestival", mock.Anything, sandbox).Return(&TestClock{FestivalID: sandbox, FrozenTime: frozen}, nil)
+	mockRepo.On("GetByFestival", mock.Anything, other).Return(nil, nil)
+	mockRepo.On("ListAll", mock.Anything).Return([]TestClock{{FestivalID: sandbox, FrozenTime: frozen}}, nil)
+
+	service := NewService(mockRepo)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.Equal(t, frozen, service.Now(ctx, sandbox))
+	assert.Equal(t, now, service.Now(ctx, other))
+
+	virtual, err := service.Virtual(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]time.Time{sandbox: frozen}, virtual)
+}
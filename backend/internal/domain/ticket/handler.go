@@ -86,7 +86,7 @@ func (h *Handler) CreateTicketType(c *gin.Context) {
 
 	var req CreateTicketTypeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -198,7 +198,7 @@ func (h *Handler) UpdateTicketType(c *gin.Context) {
 
 	var req UpdateTicketTypeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -273,7 +273,7 @@ func (h *Handler) CreateTicket(c *gin.Context) {
 
 	var req CreateTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -647,7 +647,7 @@ func (h *Handler) ScanTicket(c *gin.Context) {
 
 	var req ScanTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -718,7 +718,7 @@ func (h *Handler) TransferTicket(c *gin.Context) {
 
 	var req TransferTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -8,17 +8,31 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/transport"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
 )
 
+// PassScanner checks the parking and shuttle passes scanned at the check-in gates;
+// satisfied by transport.Service
+type PassScanner interface {
+	Scan(ctx context.Context, festivalID uuid.UUID, req transport.ScanRequest, staffID *uuid.UUID) (*transport.ScanResponse, error)
+}
+
 type Service struct {
-	repo Repository
+	repo   Repository
+	passes PassScanner
 }
 
 func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetPassScanner lets the check-in accept parking and shuttle passes, for the codes
+// that are no ticket
+func (s *Service) SetPassScanner(scanner PassScanner) {
+	s.passes = scanner
+}
+
 // TicketType operations
 
 // CreateTicketType creates a new ticket type for a festival
@@ -258,8 +272,11 @@ func (s *Service) ScanTicket(ctx context.Context, festivalID uuid.UUID, req Scan
 	// Create scan record
 	scan := s.createScanRecord(festivalID, req, scannedBy, now)
 
-	// Validate ticket exists
+	// Validate ticket exists, or check the parking or shuttle pass with the code
 	if ticket == nil {
+		if resp, err := s.scanPass(ctx, festivalID, req, scannedBy); resp != nil || err != nil {
+			return resp, err
+		}
 		return s.recordInvalidScan(ctx, scan, nil, "Ticket not found", ScanResultInvalid, now)
 	}
 
@@ -295,6 +312,38 @@ func (s *Service) ScanTicket(ctx context.Context, festivalID uuid.UUID, req Scan
 	return s.recordSuccessfulScan(ctx, scan, ticket, now)
 }
 
+// scanPass checks a code that is no ticket as a parking or shuttle pass. It returns
+// nil when the code is no pass either.
+func (s *Service) scanPass(ctx context.Context, festivalID uuid.UUID, req ScanTicketRequest, scannedBy uuid.UUID) (*ScanResponse, error) {
+	if s.passes == nil {
+		return nil, nil
+	}
+
+	result, err := s.passes.Scan(ctx, festivalID, transport.ScanRequest{
+		Code:     req.Code,
+		ScanType: transport.ScanType(req.ScanType),
+		Gate:     req.Location,
+		DeviceID: req.DeviceID,
+	}, &scannedBy)
+	if errors.Is(err, transport.ErrPassNotFound) || errors.Is(err, transport.ErrInvalidQRCode) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message := result.Message
+	if result.Product != nil {
+		message += " - " + result.Product.Name
+	}
+	return &ScanResponse{
+		Success:   result.Success,
+		Result:    ScanResult(result.Result),
+		Message:   message,
+		ScannedAt: result.ScannedAt.Format(time.RFC3339),
+	}, nil
+}
+
 // createScanRecord creates a new ticket scan record
 func (s *Service) createScanRecord(festivalID uuid.UUID, req ScanTicketRequest, scannedBy uuid.UUID, now time.Time) *TicketScan {
 	return &TicketScan{
@@ -0,0 +1,557 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the management of pass products, the passes sold and their
+// usage, which should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	products := r.Group("/transport-products")
+	{
+		products.POST("", h.CreateProduct)
+		products.PATCH("/:productId", h.UpdateProduct)
+	}
+	passes := r.Group("/transport-passes")
+	{
+		passes.GET("", h.ListPasses)
+		passes.GET("/:passId", h.GetPass)
+	}
+	r.GET("/transport-usage", h.GetUsage)
+}
+
+// RegisterStaffRoutes registers the gate scans, which should be restricted to staff
+func (h *Handler) RegisterStaffRoutes(r *gin.RouterGroup) {
+	r.POST("/transport-passes/scan", h.Scan)
+}
+
+// RegisterAttendeeRoutes registers the pass shop of the attendee app, open to every
+// authenticated user of the festival
+func (h *Handler) RegisterAttendeeRoutes(r *gin.RouterGroup) {
+	products := r.Group("/transport-products")
+	{
+		products.GET("", h.ListProducts)
+		products.GET("/:productId", h.GetProduct)
+	}
+	r.POST("/transport-passes", h.Purchase)
+
+	me := r.Group("/me/transport-passes")
+	{
+		me.GET("", h.ListMyPasses)
+		me.GET("/:passId/qr", h.GetMyPassQR)
+	}
+}
+
+// CreateProduct puts a parking or shuttle pass on sale
+// @Summary Create pass product
+// @Description Put a parking or shuttle pass on sale with the number of passes the car park or shuttles can take. Gates accept the passes from validFrom to validUntil; multiUse passes can go in again after their first entry.
+// @Tags transport
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateProductRequest true "Pass product"
+// @Success 201 {object} response.Response{data=Product} "Created product"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-products [post]
+func (h *Handler) CreateProduct(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	product, err := h.service.CreateProduct(c.Request.Context(), festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, product)
+}
+
+// UpdateProduct changes a pass product
+// @Summary Update pass product
+// @Description Change a pass product, or take it off sale with active=false. The capacity cannot go below the passes sold; a new price applies to later sales.
+// @Tags transport
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param productId path string true "Product ID" format(uuid)
+// @Param request body UpdateProductRequest true "Changes"
+// @Success 200 {object} response.Response{data=Product} "Updated product"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-products/{productId} [patch]
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	festivalID, productID, ok := productParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	product, err := h.service.UpdateProduct(c.Request.Context(), festivalID, productID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, product)
+}
+
+// ListProducts lists the pass products of the festival
+// @Summary List pass products
+// @Description List the parking and shuttle passes on sale, with the passes sold and the capacity. all=true also lists the products off sale.
+// @Tags transport
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param all query bool false "Include the products off sale"
+// @Success 200 {object} response.Response{data=[]Product} "Pass products"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-products [get]
+func (h *Handler) ListProducts(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	products, err := h.service.ListProducts(c.Request.Context(), festivalID, c.Query("all") != "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, products)
+}
+
+// GetProduct returns a pass product
+// @Summary Get pass product
+// @Description Get a parking or shuttle pass product of the festival
+// @Tags transport
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param productId path string true "Product ID" format(uuid)
+// @Success 200 {object} response.Response{data=Product} "Pass product"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-products/{productId} [get]
+func (h *Handler) GetProduct(c *gin.Context) {
+	festivalID, productID, ok := productParams(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProduct(c.Request.Context(), festivalID, productID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, product)
+}
+
+// Purchase buys a pass
+// @Summary Buy pass
+// @Description Buy a parking or shuttle pass with a wallet of the calling attendee. The price is charged to the wallet; parking passes can record the license plate of the car.
+// @Tags transport
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body PurchaseRequest true "Purchase"
+// @Success 201 {object} response.Response{data=Pass} "Bought pass"
+// @Failure 400 {object} response.ErrorResponse "Not on sale, wallet not chargeable or insufficient balance"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Product or wallet not found"
+// @Failure 409 {object} response.ErrorResponse "Sold out"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-passes [post]
+func (h *Handler) Purchase(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	var req PurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	pass, err := h.service.PurchasePass(c.Request.Context(), festivalID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, pass)
+}
+
+// ListMyPasses lists the passes of the calling attendee
+// @Summary List my passes
+// @Description List the parking and shuttle passes the calling attendee bought for the festival
+// @Tags transport
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Pass} "Passes"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/transport-passes [get]
+func (h *Handler) ListMyPasses(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	passes, err := h.service.ListUserPasses(c.Request.Context(), festivalID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, passes)
+}
+
+// GetMyPassQR returns the QR code of a pass of the calling attendee
+// @Summary Get pass QR code
+// @Description Get the signed QR code of a pass of the calling attendee as a PNG image to show at the gate, or its payload with format=json
+// @Tags transport
+// @Produce png
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param passId path string true "Pass ID" format(uuid)
+// @Param format query string false "png or json" Enums(png, json) default(png)
+// @Success 200 {file} binary "QR code image"
+// @Failure 400 {object} response.ErrorResponse "Pass cancelled"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Pass not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/me/transport-passes/{passId}/qr [get]
+func (h *Handler) GetMyPassQR(c *gin.Context) {
+	festivalID, passID, ok := passParams(c)
+	if !ok {
+		return
+	}
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("format") == "json" {
+		qr, err := h.service.GetPassQR(c.Request.Context(), festivalID, userID, passID)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		response.OK(c, qr)
+		return
+	}
+
+	png, err := h.service.RenderPassQR(c.Request.Context(), festivalID, userID, passID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+passID.String()+`.png"`)
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// ListPasses lists the passes sold
+// @Summary List passes
+// @Description List the parking and shuttle passes sold by the festival, latest first, e.g. to find the pass of a car by its license plate
+// @Tags transport
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param productId query string false "Only passes of this product" format(uuid)
+// @Param status query string false "Only passes in this status" Enums(VALID, USED, CANCELLED)
+// @Param licensePlate query string false "Only passes of this license plate"
+// @Param limit query int false "Maximum passes returned" default(100)
+// @Success 200 {object} response.Response{data=[]Pass} "Passes"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-passes [get]
+func (h *Handler) ListPasses(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	filter := PassFilter{LicensePlate: c.Query("licensePlate"), Limit: 100}
+	if raw := c.Query("productId"); raw != "" {
+		productID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid product ID", nil)
+			return
+		}
+		filter.ProductID = &productID
+	}
+	if raw := c.Query("status"); raw != "" {
+		status := PassStatus(raw)
+		switch status {
+		case PassStatusValid, PassStatusUsed, PassStatusCancelled:
+			filter.Status = &status
+		default:
+			response.BadRequest(c, "INVALID_STATUS", "Status must be VALID, USED or CANCELLED", nil)
+			return
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 1000 {
+			response.BadRequest(c, "INVALID_LIMIT", "Limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	passes, err := h.service.ListPasses(c.Request.Context(), festivalID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, passes)
+}
+
+// GetPass returns a pass sold
+// @Summary Get pass
+// @Description Get a parking or shuttle pass sold by the festival
+// @Tags transport
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param passId path string true "Pass ID" format(uuid)
+// @Success 200 {object} response.Response{data=Pass} "Pass"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Pass not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-passes/{passId} [get]
+func (h *Handler) GetPass(c *gin.Context) {
+	festivalID, passID, ok := passParams(c)
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.GetPass(c.Request.Context(), festivalID, passID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, pass)
+}
+
+// Scan checks a pass at a gate
+// @Summary Scan pass
+// @Description Check a parking or shuttle pass at a gate by its scanned QR code or typed printed code, and record the entry or exit. Like ticket check-in, refused passes are answered with success=false and the reason.
+// @Tags transport
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body ScanRequest true "Scan"
+// @Success 200 {object} response.Response{data=ScanResponse} "Scan result"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-passes/scan [post]
+func (h *Handler) Scan(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	var req ScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.Scan(c.Request.Context(), festivalID, req, userID(c))
+	if errors.Is(err, ErrPassNotFound) || errors.Is(err, ErrInvalidQRCode) {
+		message := "Pass not found"
+		if errors.Is(err, ErrInvalidQRCode) {
+			message = "Invalid pass QR code"
+		}
+		response.OK(c, ScanResponse{Result: ScanResultInvalid, Message: message, ScannedAt: time.Now()})
+		return
+	}
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+// GetUsage returns the use of the passes for traffic planning
+// @Summary Get pass usage
+// @Description Get the passes sold, used and not used of each product, the entries, exits and refused scans at the gates, the passes still in, and the traffic by hour with its peak. from and to (RFC 3339) limit the gate scans counted.
+// @Tags transport
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param from query string false "Count scans from this time" format(date-time)
+// @Param to query string false "Count scans before this time" format(date-time)
+// @Success 200 {object} response.Response{data=Usage} "Pass usage"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/transport-usage [get]
+func (h *Handler) GetUsage(c *gin.Context) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return
+	}
+
+	from, ok := timeParam(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := timeParam(c, "to")
+	if !ok {
+		return
+	}
+
+	usage, err := h.service.GetUsage(c.Request.Context(), festivalID, from, to)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, usage)
+}
+
+func festivalParam(c *gin.Context) (uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, false
+	}
+	return festivalID, true
+}
+
+func productParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid product ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, productID, true
+}
+
+func passParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, ok := festivalParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	passID, err := uuid.Parse(c.Param("passId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid pass ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, passID, true
+}
+
+// userParam returns the calling attendee
+func userParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func timeParam(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid "+name+" time, expected RFC 3339", nil)
+		return nil, false
+	}
+	return &t, true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		response.NotFound(c, "Pass product not found")
+	case errors.Is(err, ErrPassNotFound):
+		response.NotFound(c, "Pass not found")
+	case errors.Is(err, ErrWalletNotFound):
+		response.NotFound(c, "Wallet not found")
+	case errors.Is(err, ErrProductClosed):
+		response.BadRequest(c, "NOT_ON_SALE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidPeriod):
+		response.BadRequest(c, "INVALID_PERIOD", err.Error(), nil)
+	case errors.Is(err, ErrCapacityBelowSold):
+		response.BadRequest(c, "CAPACITY_BELOW_SOLD", err.Error(), nil)
+	case errors.Is(err, ErrPassCancelled):
+		response.BadRequest(c, "PASS_CANCELLED", err.Error(), nil)
+	case errors.Is(err, ErrInsufficientBalance):
+		response.BadRequest(c, "INSUFFICIENT_BALANCE", err.Error(), nil)
+	case errors.Is(err, ErrWalletNotActive):
+		response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+	case errors.Is(err, ErrChargeFailed):
+		response.BadRequest(c, "CHARGE_FAILED", err.Error(), nil)
+	case errors.Is(err, ErrSoldOut):
+		response.Conflict(c, "SOLD_OUT", err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transport errors
+var (
+	ErrProductNotFound     = errors.New("pass product not found")
+	ErrPassNotFound        = errors.New("pass not found")
+	ErrPassCancelled       = errors.New("pass has been cancelled")
+	ErrProductClosed       = errors.New("pass product is not on sale")
+	ErrSoldOut             = errors.New("pass product is sold out")
+	ErrInvalidPeriod       = errors.New("validUntil must be after validFrom")
+	ErrCapacityBelowSold   = errors.New("capacity cannot be lower than the passes sold")
+	ErrWalletNotFound      = errors.New("wallet not found")
+	ErrInsufficientBalance = errors.New("insufficient wallet balance for the pass")
+	ErrWalletNotActive     = errors.New("wallet is not active")
+	ErrChargeFailed        = errors.New("pass could not be charged")
+	ErrInvalidQRCode       = errors.New("invalid pass QR code")
+)
+
+// ProductKind is what a pass gives access to
+type ProductKind string
+
+const (
+	ProductKindParking ProductKind = "PARKING" // A parking space in a car park
+	ProductKindShuttle ProductKind = "SHUTTLE" // Seats on a shuttle route
+)
+
+// codePrefix returns the prefix of the printed codes of the passes of a kind
+func (k ProductKind) codePrefix() string {
+	if k == ProductKindShuttle {
+		return "SH"
+	}
+	return "PK"
+}
+
+// Product is a parking or shuttle pass sold to attendees, with the number of passes
+// the car park or the shuttles can take
+type Product struct {
+	ID          uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Kind        ProductKind `json:"kind" gorm:"not null"`
+	Name        string      `json:"name" gorm:"not null"`
+	Description string      `json:"description,omitempty"`
+	Location    string      `json:"location,omitempty"`    // Car park or shuttle route, e.g. "P2 North"
+	Price       int64       `json:"price" gorm:"not null"` // In cents
+	Capacity    int         `json:"capacity" gorm:"not null"`
+	Sold        int         `json:"sold" gorm:"not null"`       // Passes sold and not cancelled
+	ValidFrom   time.Time   `json:"validFrom" gorm:"not null"`  // Gates accept the passes from then
+	ValidUntil  time.Time   `json:"validUntil" gorm:"not null"` // and until then; sales stop then too
+	MultiUse    bool        `json:"multiUse" gorm:"not null"`   // Passes can go in again after their first entry
+	Active      bool        `json:"active" gorm:"not null"`     // On sale
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+func (Product) TableName() string {
+	return "transport_products"
+}
+
+// Available returns the passes that can still be sold
+func (p *Product) Available() int {
+	if p.Sold >= p.Capacity {
+		return 0
+	}
+	return p.Capacity - p.Sold
+}
+
+// PassStatus is the state of a pass
+type PassStatus string
+
+const (
+	PassStatusValid     PassStatus = "VALID"
+	PassStatusUsed      PassStatus = "USED"      // Scanned in at least once
+	PassStatusCancelled PassStatus = "CANCELLED" // The wallet could not be charged
+)
+
+// Pass is a parking or shuttle pass bought by an attendee from their wallet. Gates
+// scan its signed QR code, or staff type its printed code.
+type Pass struct {
+	ID            uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID    uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ProductID     uuid.UUID   `json:"productId" gorm:"type:uuid;not null"`
+	Kind          ProductKind `json:"kind" gorm:"not null"`
+	UserID        uuid.UUID   `json:"userId" gorm:"type:uuid;not null"`
+	WalletID      uuid.UUID   `json:"walletId" gorm:"type:uuid;not null"`
+	Code          string      `json:"code" gorm:"not null"`   // Printed code, e.g. PK-7H3K9Q2M
+	LicensePlate  string      `json:"licensePlate,omitempty"` // Parking passes only
+	Status        PassStatus  `json:"status" gorm:"not null"`
+	Amount        int64       `json:"amount" gorm:"not null"` // Price charged, in cents
+	TransactionID *uuid.UUID  `json:"transactionId,omitempty" gorm:"type:uuid"`
+	FirstUsedAt   *time.Time  `json:"firstUsedAt,omitempty"`
+	PurchasedAt   time.Time   `json:"purchasedAt" gorm:"not null"`
+}
+
+func (Pass) TableName() string {
+	return "transport_passes"
+}
+
+// ScanType is the direction a pass is scanned in at a gate
+type ScanType string
+
+const (
+	ScanTypeEntry ScanType = "ENTRY"
+	ScanTypeExit  ScanType = "EXIT"
+	ScanTypeCheck ScanType = "CHECK" // Just verifying, no entry or exit
+)
+
+// ScanResult is the outcome of a gate scan. The values are those of ticket check-in.
+type ScanResult string
+
+const (
+	ScanResultSuccess ScanResult = "SUCCESS"
+	ScanResultAlready ScanResult = "ALREADY_USED"
+	ScanResultExpired ScanResult = "EXPIRED"
+	ScanResultInvalid ScanResult = "INVALID"
+)
+
+// PassScan records a scan of a pass at a gate, accepted or not
+type PassScan struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ProductID  uuid.UUID  `json:"productId" gorm:"type:uuid;not null"`
+	PassID     uuid.UUID  `json:"passId" gorm:"type:uuid;not null"`
+	ScanType   ScanType   `json:"scanType" gorm:"not null"`
+	Result     ScanResult `json:"result" gorm:"not null"`
+	Message    string     `json:"message,omitempty"`
+	Gate       string     `json:"gate,omitempty"`
+	DeviceID   string     `json:"deviceId,omitempty"`
+	ScannedBy  *uuid.UUID `json:"scannedBy,omitempty" gorm:"type:uuid"`
+	ScannedAt  time.Time  `json:"scannedAt" gorm:"not null"`
+}
+
+func (PassScan) TableName() string {
+	return "transport_pass_scans"
+}
+
+// PassFilter narrows the listed passes
+type PassFilter struct {
+	ProductID    *uuid.UUID
+	UserID       *uuid.UUID
+	Status       *PassStatus
+	LicensePlate string
+	Limit        int
+}
+
+// PassQR is the signed QR code payload of a pass, shown in the attendee app
+type PassQR struct {
+	PassID    uuid.UUID `json:"passId"`
+	Code      string    `json:"code"`
+	Payload   string    `json:"payload"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ScanResponse is the answer to a gate scan
+type ScanResponse struct {
+	Success   bool       `json:"success"`
+	Result    ScanResult `json:"result"`
+	Message   string     `json:"message"`
+	Pass      *Pass      `json:"pass"`
+	Product   *Product   `json:"product"`
+	ScannedAt time.Time  `json:"scannedAt"`
+}
+
+// ProductUsage is the sales and gate traffic of a pass product
+type ProductUsage struct {
+	ProductID   uuid.UUID   `json:"productId"`
+	Name        string      `json:"name"`
+	Kind        ProductKind `json:"kind"`
+	Capacity    int64       `json:"capacity"`
+	Sold        int64       `json:"sold"`
+	Revenue     int64       `json:"revenue"`    // In cents
+	PassesUsed  int64       `json:"passesUsed"` // Passes scanned in at least once
+	NoShows     int64       `json:"noShows"`    // Passes sold and never scanned in
+	Entries     int64       `json:"entries"`
+	Exits       int64       `json:"exits"`
+	Rejected    int64       `json:"rejected"` // Scans refused at the gates
+	Present     int64       `json:"present"`  // Entries not followed by an exit yet, e.g. cars parked
+	PeakHour    *time.Time  `json:"peakHour,omitempty"`
+	PeakEntries int64       `json:"peakEntries"`
+}
+
+// HourlyTraffic is the passes scanned in and out at the gates of a product in an hour
+type HourlyTraffic struct {
+	ProductID uuid.UUID `json:"productId"`
+	Hour      time.Time `json:"hour"`
+	Entries   int64     `json:"entries"`
+	Exits     int64     `json:"exits"`
+}
+
+// Usage is the use of the parking and shuttle passes of a festival for traffic planning
+type Usage struct {
+	FestivalID  uuid.UUID       `json:"festivalId"`
+	From        *time.Time      `json:"from,omitempty"`
+	To          *time.Time      `json:"to,omitempty"`
+	Products    []ProductUsage  `json:"products"`
+	Hours       []HourlyTraffic `json:"hours"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// CreateProductRequest adds a parking or shuttle pass for sale
+type CreateProductRequest struct {
+	Kind        ProductKind `json:"kind" binding:"required,oneof=PARKING SHUTTLE"`
+	Name        string      `json:"name" binding:"required,max=100"`
+	Description string      `json:"description,omitempty" binding:"max=1000"`
+	Location    string      `json:"location,omitempty" binding:"max=255"`
+	Price       int64       `json:"price" binding:"min=0"`
+	Capacity    int         `json:"capacity" binding:"required,min=1"`
+	ValidFrom   time.Time   `json:"validFrom" binding:"required"`
+	ValidUntil  time.Time   `json:"validUntil" binding:"required"`
+	MultiUse    bool        `json:"multiUse"`
+}
+
+// UpdateProductRequest changes a pass product; a new price applies to the passes sold
+// afterwards
+type UpdateProductRequest struct {
+	Name        *string    `json:"name,omitempty" binding:"omitempty,max=100"`
+	Description *string    `json:"description,omitempty" binding:"omitempty,max=1000"`
+	Location    *string    `json:"location,omitempty" binding:"omitempty,max=255"`
+	Price       *int64     `json:"price,omitempty" binding:"omitempty,min=0"`
+	Capacity    *int       `json:"capacity,omitempty" binding:"omitempty,min=1"`
+	ValidFrom   *time.Time `json:"validFrom,omitempty"`
+	ValidUntil  *time.Time `json:"validUntil,omitempty"`
+	MultiUse    *bool      `json:"multiUse,omitempty"`
+	Active      *bool      `json:"active,omitempty"`
+}
+
+// PurchaseRequest buys a pass with a wallet of the attendee
+type PurchaseRequest struct {
+	ProductID    uuid.UUID `json:"productId" binding:"required"`
+	WalletID     uuid.UUID `json:"walletId" binding:"required"`
+	LicensePlate string    `json:"licensePlate,omitempty" binding:"max=20"`
+}
+
+// ScanRequest checks a pass at a gate. Code is the scanned QR code payload or the
+// printed code of the pass.
+type ScanRequest struct {
+	Code      string     `json:"code" binding:"required"`
+	ScanType  ScanType   `json:"scanType" binding:"required,oneof=ENTRY EXIT CHECK"`
+	ProductID *uuid.UUID `json:"productId,omitempty"` // Only accept the passes of this product, e.g. at a car park gate
+	Gate      string     `json:"gate,omitempty" binding:"max=100"`
+	DeviceID  string     `json:"deviceId,omitempty" binding:"max=100"`
+}
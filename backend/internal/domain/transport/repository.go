@@ -0,0 +1,302 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// errCodeTaken is returned when another pass has the printed code
+var errCodeTaken = errors.New("pass code is taken")
+
+type Repository interface {
+	CreateProduct(ctx context.Context, product *Product) error
+	UpdateProduct(ctx context.Context, product *Product) error
+	GetProduct(ctx context.Context, festivalID, id uuid.UUID) (*Product, error)
+	ListProducts(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Product, error)
+
+	CreatePass(ctx context.Context, pass *Pass) error
+	UpdatePass(ctx context.Context, pass *Pass) error
+	CancelPass(ctx context.Context, pass *Pass) error
+	GetPass(ctx context.Context, festivalID, id uuid.UUID) (*Pass, error)
+	GetPassByCode(ctx context.Context, festivalID uuid.UUID, code string) (*Pass, error)
+	ListPasses(ctx context.Context, festivalID uuid.UUID, filter PassFilter) ([]Pass, error)
+
+	RecordScan(ctx context.Context, scan *PassScan, pass *Pass) error
+	GetProductUsage(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) ([]ProductUsage, error)
+	GetHourlyTraffic(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) ([]HourlyTraffic, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateProduct(ctx context.Context, product *Product) error {
+	if err := r.db.WithContext(ctx).Create(product).Error; err != nil {
+		return fmt.Errorf("failed to create pass product: %w", err)
+	}
+	return nil
+}
+
+// UpdateProduct saves the settings of a product, leaving the passes sold to the sales.
+// The capacity is only lowered down to the passes sold.
+func (r *repository) UpdateProduct(ctx context.Context, product *Product) error {
+	result := r.db.WithContext(ctx).Model(&Product{}).
+		Where("id = ? AND sold <= ?", product.ID, product.Capacity).
+		Updates(map[string]interface{}{
+			"name":        product.Name,
+			"description": product.Description,
+			"location":    product.Location,
+			"price":       product.Price,
+			"capacity":    product.Capacity,
+			"valid_from":  product.ValidFrom,
+			"valid_until": product.ValidUntil,
+			"multi_use":   product.MultiUse,
+			"active":      product.Active,
+			"updated_at":  product.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update pass product: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCapacityBelowSold
+	}
+	return nil
+}
+
+func (r *repository) GetProduct(ctx context.Context, festivalID, id uuid.UUID) (*Product, error) {
+	var product Product
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&product).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pass product: %w", err)
+	}
+	return &product, nil
+}
+
+func (r *repository) ListProducts(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Product, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	var products []Product
+	if err := query.Order("kind, valid_from, name").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pass products: %w", err)
+	}
+	return products, nil
+}
+
+// CreatePass takes a place of the product of the pass and records the pass. The
+// place is taken by a conditional update, so that concurrent sales never exceed the
+// capacity.
+func (r *repository) CreatePass(ctx context.Context, pass *Pass) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Product{}).
+			Where("id = ? AND sold < capacity", pass.ProductID).
+			Update("sold", gorm.Expr("sold + 1"))
+		if result.Error != nil {
+			return fmt.Errorf("failed to reserve pass: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrSoldOut
+		}
+
+		if err := tx.Create(pass).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return errCodeTaken
+			}
+			return fmt.Errorf("failed to create pass: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) UpdatePass(ctx context.Context, pass *Pass) error {
+	if err := r.db.WithContext(ctx).Save(pass).Error; err != nil {
+		return fmt.Errorf("failed to update pass: %w", err)
+	}
+	return nil
+}
+
+// CancelPass records the cancellation of a pass and gives its place back
+func (r *repository) CancelPass(ctx context.Context, pass *Pass) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(pass).Error; err != nil {
+			return fmt.Errorf("failed to cancel pass: %w", err)
+		}
+		err := tx.Model(&Product{}).Where("id = ? AND sold > 0", pass.ProductID).
+			Update("sold", gorm.Expr("sold - 1")).Error
+		if err != nil {
+			return fmt.Errorf("failed to release pass: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetPass(ctx context.Context, festivalID, id uuid.UUID) (*Pass, error) {
+	var pass Pass
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND id = ?", festivalID, id).First(&pass).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+	return &pass, nil
+}
+
+func (r *repository) GetPassByCode(ctx context.Context, festivalID uuid.UUID, code string) (*Pass, error) {
+	var pass Pass
+	err := r.db.WithContext(ctx).Where("festival_id = ? AND code = ?", festivalID, code).First(&pass).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+	return &pass, nil
+}
+
+func (r *repository) ListPasses(ctx context.Context, festivalID uuid.UUID, filter PassFilter) ([]Pass, error) {
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if filter.ProductID != nil {
+		query = query.Where("product_id = ?", *filter.ProductID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.LicensePlate != "" {
+		query = query.Where("license_plate = ?", filter.LicensePlate)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var passes []Pass
+	if err := query.Order("purchased_at DESC").Find(&passes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list passes: %w", err)
+	}
+	return passes, nil
+}
+
+// RecordScan records a gate scan, and the change of the pass it used when given
+func (r *repository) RecordScan(ctx context.Context, scan *PassScan, pass *Pass) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(scan).Error; err != nil {
+			return fmt.Errorf("failed to record pass scan: %w", err)
+		}
+		if pass != nil {
+			if err := tx.Save(pass).Error; err != nil {
+				return fmt.Errorf("failed to update pass: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetProductUsage sums the sales and the gate scans of each product. A pass is present
+// when its latest accepted entry or exit is an entry.
+func (r *repository) GetProductUsage(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) ([]ProductUsage, error) {
+	scans, args := scanPeriod(festivalID, from, to)
+
+	var usage []ProductUsage
+	err := r.db.WithContext(ctx).Raw(`
+		WITH scans AS (`+scans+`),
+		moves AS (
+			SELECT DISTINCT ON (pass_id) pass_id, product_id, scan_type
+			FROM scans
+			WHERE result = 'SUCCESS' AND scan_type IN ('ENTRY', 'EXIT')
+			ORDER BY pass_id, scanned_at DESC
+		)
+		SELECT
+			p.id AS product_id,
+			p.name,
+			p.kind,
+			p.capacity,
+			COALESCE(s.sold, 0) AS sold,
+			COALESCE(s.revenue, 0) AS revenue,
+			COALESCE(g.passes_used, 0) AS passes_used,
+			COALESCE(g.entries, 0) AS entries,
+			COALESCE(g.exits, 0) AS exits,
+			COALESCE(g.rejected, 0) AS rejected,
+			(SELECT COUNT(*) FROM moves m WHERE m.product_id = p.id AND m.scan_type = 'ENTRY') AS present
+		FROM transport_products p
+		LEFT JOIN (
+			SELECT product_id, COUNT(*) AS sold, SUM(amount) AS revenue
+			FROM transport_passes
+			WHERE festival_id = ? AND status <> 'CANCELLED'
+			GROUP BY product_id
+		) s ON s.product_id = p.id
+		LEFT JOIN (
+			SELECT product_id,
+				COUNT(DISTINCT pass_id) FILTER (WHERE result = 'SUCCESS' AND scan_type = 'ENTRY') AS passes_used,
+				COUNT(*) FILTER (WHERE result = 'SUCCESS' AND scan_type = 'ENTRY') AS entries,
+				COUNT(*) FILTER (WHERE result = 'SUCCESS' AND scan_type = 'EXIT') AS exits,
+				COUNT(*) FILTER (WHERE result <> 'SUCCESS') AS rejected
+			FROM scans
+			GROUP BY product_id
+		) g ON g.product_id = p.id
+		WHERE p.festival_id = ?
+		ORDER BY p.kind, p.name`,
+		append(args, festivalID, festivalID)...,
+	).Scan(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass usage: %w", err)
+	}
+	return usage, nil
+}
+
+// GetHourlyTraffic counts the accepted entries and exits of each product by hour
+func (r *repository) GetHourlyTraffic(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) ([]HourlyTraffic, error) {
+	scans, args := scanPeriod(festivalID, from, to)
+
+	var hours []HourlyTraffic
+	err := r.db.WithContext(ctx).Raw(`
+		WITH scans AS (`+scans+`)
+		SELECT
+			product_id,
+			date_trunc('hour', scanned_at) AS hour,
+			COUNT(*) FILTER (WHERE scan_type = 'ENTRY') AS entries,
+			COUNT(*) FILTER (WHERE scan_type = 'EXIT') AS exits
+		FROM scans
+		WHERE result = 'SUCCESS' AND scan_type IN ('ENTRY', 'EXIT')
+		GROUP BY product_id, hour
+		ORDER BY hour, product_id`,
+		args...,
+	).Scan(&hours).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass traffic: %w", err)
+	}
+	return hours, nil
+}
+
+// scanPeriod selects the gate scans of a festival within the period
+func scanPeriod(festivalID uuid.UUID, from, to *time.Time) (string, []interface{}) {
+	query := `SELECT * FROM transport_pass_scans WHERE festival_id = ?`
+	args := []interface{}{festivalID}
+	if from != nil {
+		query += ` AND scanned_at >= ?`
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += ` AND scanned_at < ?`
+		args = append(args, *to)
+	}
+	return query, args
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateProduct(ctx context.Context, product *Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateProduct(ctx context.Context, product *Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetProduct(ctx context.Context, festivalID, id uuid.UUID) (*Product, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Product), args.Error(1)
+}
+
+func (m *MockRepository) ListProducts(ctx context.Context, festivalID uuid.UUID, activeOnly bool) ([]Product, error) {
+	args := m.Called(ctx, festivalID, activeOnly)
+	return args.Get(0).([]Product), args.Error(1)
+}
+
+func (m *MockRepository) CreatePass(ctx context.Context, pass *Pass) error {
+	args := m.Called(ctx, pass)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdatePass(ctx context.Context, pass *Pass) error {
+	args := m.Called(ctx, pass)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CancelPass(ctx context.Context, pass *Pass) error {
+	args := m.Called(ctx, pass)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPass(ctx context.Context, festivalID, id uuid.UUID) (*Pass, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pass), args.Error(1)
+}
+
+func (m *MockRepository) GetPassByCode(ctx context.Context, festivalID uuid.UUID, code string) (*Pass, error) {
+	args := m.Called(ctx, festivalID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pass), args.Error(1)
+}
+
+func (m *MockRepository) ListPasses(ctx context.Context, festivalID uuid.UUID, filter PassFilter) ([]Pass, error) {
+	args := m.Called(ctx, festivalID, filter)
+	return args.Get(0).([]Pass), args.Error(1)
+}
+
+func (m *MockRepository) RecordScan(ctx context.Context, scan *PassScan, pass *Pass) error {
+	args := m.Called(ctx, scan, pass)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetProductUsage(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) ([]ProductUsage, error) {
+	args := m.Called(ctx, festivalID, from, to)
+	return args.Get(0).([]ProductUsage), args.Error(1)
+}
+
+func (m *MockRepository) GetHourlyTraffic(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) ([]HourlyTraffic, error) {
+	args := m.Called(ctx, festivalID, from, to)
+	return args.Get(0).([]HourlyTraffic), args.Error(1)
+}
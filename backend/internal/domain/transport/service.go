@@ -0,0 +1,504 @@
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/qrcode"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+)
+
+// codeAlphabet leaves out the characters mistaken for one another on printed passes
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeLength is the length of the printed codes after their kind prefix
+const codeLength = 8
+
+// maxPrintedCodeLength tells printed codes from QR code payloads, which are far longer
+const maxPrintedCodeLength = 16
+
+// Wallets charges passes to the wallets of attendees; satisfied by wallet.Service
+type Wallets interface {
+	GetWallet(ctx context.Context, id uuid.UUID) (*wallet.Wallet, error)
+	Adjust(ctx context.Context, walletID uuid.UUID, req wallet.AdjustRequest, staffID *uuid.UUID) (*wallet.Transaction, error)
+}
+
+// PassCodes signs and verifies the QR codes of passes in the format of ticket QR codes,
+// so that the check-in scanners read both; satisfied by qrcode.Generator
+type PassCodes interface {
+	GenerateQRDataOnly(ticketID, userID, festivalID uuid.UUID, ticketCode string, validUntil time.Time) (string, error)
+	VerifyAndDecodePayload(encodedPayload string) (*qrcode.Payload, error)
+	GenerateQRFromData(encodedPayload string) ([]byte, error)
+}
+
+// Service sells the parking and shuttle passes of festivals, checks them at the gates
+// and reports their use for traffic planning
+type Service struct {
+	repo    Repository
+	wallets Wallets
+	codes   PassCodes
+	now     func() time.Time
+}
+
+// NewService creates the transport pass service
+func NewService(repo Repository, wallets Wallets, codes PassCodes) *Service {
+	return &Service{
+		repo:    repo,
+		wallets: wallets,
+		codes:   codes,
+		now:     time.Now,
+	}
+}
+
+// CreateProduct puts a parking or shuttle pass on sale
+func (s *Service) CreateProduct(ctx context.Context, festivalID uuid.UUID, req CreateProductRequest) (*Product, error) {
+	if !req.ValidUntil.After(req.ValidFrom) {
+		return nil, ErrInvalidPeriod
+	}
+
+	now := s.now()
+	product := &Product{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		Kind:        req.Kind,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Location:    strings.TrimSpace(req.Location),
+		Price:       req.Price,
+		Capacity:    req.Capacity,
+		ValidFrom:   req.ValidFrom,
+		ValidUntil:  req.ValidUntil,
+		MultiUse:    req.MultiUse,
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateProduct(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// UpdateProduct changes a pass product. Taking it off sale stops new sales; the passes
+// sold stay valid at the gates.
+func (s *Service) UpdateProduct(ctx context.Context, festivalID, id uuid.UUID, req UpdateProductRequest) (*Product, error) {
+	product, err := s.GetProduct(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		product.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		product.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Location != nil {
+		product.Location = strings.TrimSpace(*req.Location)
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
+	}
+	if req.Capacity != nil {
+		product.Capacity = *req.Capacity
+	}
+	if req.ValidFrom != nil {
+		product.ValidFrom = *req.ValidFrom
+	}
+	if req.ValidUntil != nil {
+		product.ValidUntil = *req.ValidUntil
+	}
+	if req.MultiUse != nil {
+		product.MultiUse = *req.MultiUse
+	}
+	if req.Active != nil {
+		product.Active = *req.Active
+	}
+	if !product.ValidUntil.After(product.ValidFrom) {
+		return nil, ErrInvalidPeriod
+	}
+	if product.Capacity < product.Sold {
+		return nil, ErrCapacityBelowSold
+	}
+	product.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateProduct(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetProduct returns a pass product of the festival
+func (s *Service) GetProduct(ctx context.Context, festivalID, id uuid.UUID) (*Product, error) {
+	product, err := s.repo.GetProduct(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// ListProducts lists the pass products of the festival, or only those on sale
+func (s *Service) ListProducts(ctx context.Context, festivalID uuid.UUID, onSaleOnly bool) ([]Product, error) {
+	products, err := s.repo.ListProducts(ctx, festivalID, onSaleOnly)
+	if err != nil || !onSaleOnly {
+		return products, err
+	}
+
+	now := s.now()
+	onSale := make([]Product, 0, len(products))
+	for _, product := range products {
+		if now.Before(product.ValidUntil) {
+			onSale = append(onSale, product)
+		}
+	}
+	return onSale, nil
+}
+
+// PurchasePass sells a pass to an attendee and charges its price to their wallet. The
+// place is given back when the charge fails.
+func (s *Service) PurchasePass(ctx context.Context, festivalID, userID uuid.UUID, req PurchaseRequest) (*Pass, error) {
+	product, err := s.GetProduct(ctx, festivalID, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Active || !s.now().Before(product.ValidUntil) {
+		return nil, ErrProductClosed
+	}
+	if err := s.checkWallet(ctx, festivalID, userID, req.WalletID); err != nil {
+		return nil, err
+	}
+
+	pass := &Pass{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		ProductID:   product.ID,
+		Kind:        product.Kind,
+		UserID:      userID,
+		WalletID:    req.WalletID,
+		Status:      PassStatusValid,
+		Amount:      product.Price,
+		PurchasedAt: s.now(),
+	}
+	if product.Kind == ProductKindParking {
+		pass.LicensePlate = NormalizeLicensePlate(req.LicensePlate)
+	}
+
+	// Printed codes are unique within the festival; draw again on the rare collision
+	for attempt := 0; ; attempt++ {
+		if pass.Code, err = newPassCode(product.Kind); err != nil {
+			return nil, err
+		}
+		err = s.repo.CreatePass(ctx, pass)
+		if !errors.Is(err, errCodeTaken) || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Price > 0 {
+		tx, err := s.charge(ctx, pass, product)
+		if err != nil {
+			pass.Status = PassStatusCancelled
+			pass.Amount = 0
+			if cancelErr := s.repo.CancelPass(ctx, pass); cancelErr != nil {
+				return nil, cancelErr
+			}
+			return nil, err
+		}
+		pass.TransactionID = &tx.ID
+		if err := s.repo.UpdatePass(ctx, pass); err != nil {
+			return nil, err
+		}
+	}
+	return pass, nil
+}
+
+// GetPass returns a pass of the festival
+func (s *Service) GetPass(ctx context.Context, festivalID, id uuid.UUID) (*Pass, error) {
+	pass, err := s.repo.GetPass(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if pass == nil {
+		return nil, ErrPassNotFound
+	}
+	return pass, nil
+}
+
+// ListPasses lists the passes sold by the festival, latest first
+func (s *Service) ListPasses(ctx context.Context, festivalID uuid.UUID, filter PassFilter) ([]Pass, error) {
+	filter.LicensePlate = NormalizeLicensePlate(filter.LicensePlate)
+	return s.repo.ListPasses(ctx, festivalID, filter)
+}
+
+// ListUserPasses lists the passes an attendee bought for the festival
+func (s *Service) ListUserPasses(ctx context.Context, festivalID, userID uuid.UUID) ([]Pass, error) {
+	return s.repo.ListPasses(ctx, festivalID, PassFilter{UserID: &userID})
+}
+
+// GetPassQR returns the signed QR code payload of a pass of the attendee
+func (s *Service) GetPassQR(ctx context.Context, festivalID, userID, passID uuid.UUID) (*PassQR, error) {
+	pass, err := s.GetPass(ctx, festivalID, passID)
+	if err != nil {
+		return nil, err
+	}
+	if pass.UserID != userID {
+		return nil, ErrPassNotFound
+	}
+	if pass.Status == PassStatusCancelled {
+		return nil, ErrPassCancelled
+	}
+	product, err := s.GetProduct(ctx, festivalID, pass.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := s.codes.GenerateQRDataOnly(pass.ID, pass.UserID, pass.FestivalID, pass.Code, product.ValidUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign pass QR code: %w", err)
+	}
+	return &PassQR{
+		PassID:    pass.ID,
+		Code:      pass.Code,
+		Payload:   payload,
+		ExpiresAt: product.ValidUntil,
+	}, nil
+}
+
+// RenderPassQR renders the QR code of a pass of the attendee as a PNG image
+func (s *Service) RenderPassQR(ctx context.Context, festivalID, userID, passID uuid.UUID) ([]byte, error) {
+	qr, err := s.GetPassQR(ctx, festivalID, userID, passID)
+	if err != nil {
+		return nil, err
+	}
+	return s.codes.GenerateQRFromData(qr.Payload)
+}
+
+// Scan checks a pass at a gate and records the scan. The first accepted entry uses the
+// pass; single-use passes are refused on later entries. Codes that are no pass of the
+// festival return ErrPassNotFound or ErrInvalidQRCode and are not recorded, so that
+// the ticket check-in can handle them.
+func (s *Service) Scan(ctx context.Context, festivalID uuid.UUID, req ScanRequest, staffID *uuid.UUID) (*ScanResponse, error) {
+	pass, err := s.resolve(ctx, festivalID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.GetProduct(ctx, festivalID, pass.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	result, message := CheckPass(pass, product, req, now)
+	scan := &PassScan{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		ProductID:  pass.ProductID,
+		PassID:     pass.ID,
+		ScanType:   req.ScanType,
+		Result:     result,
+		Message:    message,
+		Gate:       strings.TrimSpace(req.Gate),
+		DeviceID:   strings.TrimSpace(req.DeviceID),
+		ScannedBy:  staffID,
+		ScannedAt:  now,
+	}
+
+	var used *Pass
+	if result == ScanResultSuccess && req.ScanType == ScanTypeEntry && pass.Status == PassStatusValid {
+		pass.Status = PassStatusUsed
+		pass.FirstUsedAt = &now
+		used = pass
+	}
+	if err := s.repo.RecordScan(ctx, scan, used); err != nil {
+		return nil, err
+	}
+
+	return &ScanResponse{
+		Success:   result == ScanResultSuccess,
+		Result:    result,
+		Message:   message,
+		Pass:      pass,
+		Product:   product,
+		ScannedAt: now,
+	}, nil
+}
+
+// CheckPass decides whether a gate accepts a pass, the way ticket check-in decides for
+// tickets
+func CheckPass(pass *Pass, product *Product, req ScanRequest, now time.Time) (ScanResult, string) {
+	switch {
+	case pass.Status == PassStatusCancelled:
+		return ScanResultInvalid, "Pass has been cancelled"
+	case req.ProductID != nil && *req.ProductID != pass.ProductID:
+		return ScanResultInvalid, "Pass is for " + product.Name
+	case now.Before(product.ValidFrom):
+		return ScanResultInvalid, "Pass not yet valid"
+	case now.After(product.ValidUntil):
+		return ScanResultExpired, "Pass has expired"
+	case req.ScanType == ScanTypeEntry && pass.Status == PassStatusUsed && !product.MultiUse:
+		return ScanResultAlready, "Pass already used - reentry not allowed"
+	}
+	return ScanResultSuccess, "Valid pass"
+}
+
+// GetUsage returns the sales and gate traffic of the passes of the festival, within
+// the period when given
+func (s *Service) GetUsage(ctx context.Context, festivalID uuid.UUID, from, to *time.Time) (*Usage, error) {
+	products, err := s.repo.GetProductUsage(ctx, festivalID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := s.repo.GetHourlyTraffic(ctx, festivalID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := ComputeUsage(products, hours)
+	usage.FestivalID = festivalID
+	usage.From = from
+	usage.To = to
+	usage.GeneratedAt = s.now()
+	return usage, nil
+}
+
+// ComputeUsage completes the usage of each product with its no-shows and the hour the
+// most passes were scanned in
+func ComputeUsage(products []ProductUsage, hours []HourlyTraffic) *Usage {
+	peaks := make(map[uuid.UUID]HourlyTraffic)
+	for _, hour := range hours {
+		if peak, ok := peaks[hour.ProductID]; !ok || hour.Entries > peak.Entries {
+			peaks[hour.ProductID] = hour
+		}
+	}
+
+	usage := &Usage{Products: make([]ProductUsage, len(products)), Hours: hours}
+	if usage.Hours == nil {
+		usage.Hours = []HourlyTraffic{}
+	}
+	for i, product := range products {
+		if product.Sold > product.PassesUsed {
+			product.NoShows = product.Sold - product.PassesUsed
+		}
+		if peak, ok := peaks[product.ProductID]; ok && peak.Entries > 0 {
+			hour := peak.Hour
+			product.PeakHour = &hour
+			product.PeakEntries = peak.Entries
+		}
+		usage.Products[i] = product
+	}
+	return usage
+}
+
+// NormalizePassCode formats a printed pass code as printed, ignoring case, spaces and
+// dashes
+func NormalizePassCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	if len(code) <= 2 {
+		return code
+	}
+	return code[:2] + "-" + code[2:]
+}
+
+// NormalizeLicensePlate formats a license plate for lookups, ignoring case, spaces and
+// dashes
+func NormalizeLicensePlate(plate string) string {
+	plate = strings.ToUpper(strings.TrimSpace(plate))
+	return strings.NewReplacer(" ", "", "-", "").Replace(plate)
+}
+
+// resolve finds the pass of a scanned QR code payload or of a typed printed code
+func (s *Service) resolve(ctx context.Context, festivalID uuid.UUID, code string) (*Pass, error) {
+	code = strings.TrimSpace(code)
+	if len(code) <= maxPrintedCodeLength {
+		pass, err := s.repo.GetPassByCode(ctx, festivalID, NormalizePassCode(code))
+		if err != nil {
+			return nil, err
+		}
+		if pass == nil {
+			return nil, ErrPassNotFound
+		}
+		return pass, nil
+	}
+
+	payload, err := s.codes.VerifyAndDecodePayload(code)
+	if err != nil {
+		return nil, ErrInvalidQRCode
+	}
+	if payload.FestivalID != festivalID {
+		return nil, ErrPassNotFound
+	}
+	pass, err := s.repo.GetPass(ctx, festivalID, payload.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	if pass == nil {
+		return nil, ErrPassNotFound
+	}
+	if pass.Code != payload.TicketCode {
+		return nil, ErrInvalidQRCode
+	}
+	return pass, nil
+}
+
+// checkWallet checks that a wallet of the attendee for the festival can be charged
+func (s *Service) checkWallet(ctx context.Context, festivalID, userID, walletID uuid.UUID) error {
+	w, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return ErrWalletNotFound
+		}
+		return err
+	}
+	if w.FestivalID != festivalID || w.UserID == nil || *w.UserID != userID {
+		return ErrWalletNotFound
+	}
+	if w.Status != wallet.WalletStatusActive {
+		return ErrWalletNotActive
+	}
+	return nil
+}
+
+// charge debits the price of a pass from its wallet under the pass ID
+func (s *Service) charge(ctx context.Context, pass *Pass, product *Product) (*wallet.Transaction, error) {
+	tx, err := s.wallets.Adjust(ctx, pass.WalletID, wallet.AdjustRequest{
+		TransactionID: pass.ID,
+		Amount:        -product.Price,
+		Reason:        product.Name + " pass " + pass.Code,
+		Reference:     "transport_pass:" + pass.ID.String(),
+	}, nil)
+	switch {
+	case err == nil:
+		return tx, nil
+	case errors.Is(err, wallet.ErrAdjustmentInsufficient):
+		return nil, ErrInsufficientBalance
+	case errors.Is(err, wallet.ErrAdjustmentNotActive):
+		return nil, ErrWalletNotActive
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrChargeFailed, err)
+	}
+}
+
+func newPassCode(kind ProductKind) (string, error) {
+	raw := make([]byte, codeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate pass code: %w", err)
+	}
+	code := make([]byte, codeLength)
+	for i, b := range raw {
+		code[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return kind.codePrefix() + "-" + string(code), nil
+}
@@ -0,0 +1,298 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/qrcode"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWallets debits wallets idempotently by transaction ID like wallet.Service.Adjust
+type fakeWallets struct {
+	wallets map[uuid.UUID]*wallet.Wallet
+	applied map[uuid.UUID]bool
+}
+
+func (w *fakeWallets) GetWallet(ctx context.Context, id uuid.UUID) (*wallet.Wallet, error) {
+	if found, ok := w.wallets[id]; ok {
+		return found, nil
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (w *fakeWallets) Adjust(ctx context.Context, walletID uuid.UUID, req wallet.AdjustRequest, staffID *uuid.UUID) (*wallet.Transaction, error) {
+	if w.applied[req.TransactionID] {
+		return nil, wallet.ErrAdjustmentApplied
+	}
+	found := w.wallets[walletID]
+	if found.Balance+req.Amount < 0 {
+		return nil, wallet.ErrAdjustmentInsufficient
+	}
+	w.applied[req.TransactionID] = true
+	found.Balance += req.Amount
+	return &wallet.Transaction{ID: req.TransactionID, WalletID: walletID, Amount: req.Amount}, nil
+}
+
+// fakeCodes "signs" payloads by joining their fields behind a fixed marker
+type fakeCodes struct{}
+
+func (fakeCodes) GenerateQRDataOnly(ticketID, userID, festivalID uuid.UUID, ticketCode string, validUntil time.Time) (string, error) {
+	return strings.Join([]string{"signed", ticketID.String(), userID.String(), festivalID.String(), ticketCode}, "|"), nil
+}
+
+func (fakeCodes) VerifyAndDecodePayload(encodedPayload string) (*qrcode.Payload, error) {
+	parts := strings.Split(encodedPayload, "|")
+	if len(parts) != 5 || parts[0] != "signed" {
+		return nil, errors.New("invalid signature")
+	}
+	return &qrcode.Payload{
+		TicketID:   uuid.MustParse(parts[1]),
+		UserID:     uuid.MustParse(parts[2]),
+		FestivalID: uuid.MustParse(parts[3]),
+		TicketCode: parts[4],
+	}, nil
+}
+
+func (fakeCodes) GenerateQRFromData(encodedPayload string) ([]byte, error) {
+	return []byte(encodedPayload), nil
+}
+
+type transportFixture struct {
+	service    *Service
+	mockRepo   *MockRepository
+	wallets    *fakeWallets
+	festivalID uuid.UUID
+	userID     uuid.UUID
+	walletID   uuid.UUID
+	now        time.Time
+}
+
+// newTransportFixture sets up a festival day at noon and an attendee wallet holding
+// balance
+func newTransportFixture(balance int64) *transportFixture {
+	f := &transportFixture{
+		mockRepo:   NewMockRepository(),
+		wallets:    &fakeWallets{wallets: map[uuid.UUID]*wallet.Wallet{}, applied: map[uuid.UUID]bool{}},
+		festivalID: uuid.New(),
+		userID:     uuid.New(),
+		walletID:   uuid.New(),
+		now:        time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC),
+	}
+	f.wallets.wallets[f.walletID] = &wallet.Wallet{ID: f.walletID, UserID: &f.userID, FestivalID: f.festivalID, Balance: balance, Status: wallet.WalletStatusActive}
+	f.service = NewService(f.mockRepo, f.wallets, fakeCodes{})
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+// newProduct puts on sale a pass of the day for 15.00. The repository returns the
+// product from then on, counting its places in Sold.
+func (f *transportFixture) newProduct(t *testing.T, kind ProductKind, capacity int, multiUse bool) *Product {
+	f.mockRepo.On("CreateProduct", mock.Anything, mock.AnythingOfType("*transport.Product")).Return(nil).Once()
+	product, err := f.service.CreateProduct(context.Background(), f.festivalID, CreateProductRequest{
+		Kind:       kind,
+		Name:       " P2 North ",
+		Price:      1500,
+		Capacity:   capacity,
+		ValidFrom:  time.Date(2026, 7, 18, 8, 0, 0, 0, time.UTC),
+		ValidUntil: time.Date(2026, 7, 19, 4, 0, 0, 0, time.UTC),
+		MultiUse:   multiUse,
+	})
+	require.NoError(t, err)
+	f.mockRepo.On("GetProduct", mock.Anything, f.festivalID, product.ID).Return(product, nil)
+	return product
+}
+
+// expectCreatePass takes a place of the product for the next pass created
+func (f *transportFixture) expectCreatePass(product *Product) {
+	f.mockRepo.On("CreatePass", mock.Anything, mock.AnythingOfType("*transport.Pass")).
+		Run(func(args mock.Arguments) { product.Sold++ }).
+		Return(nil).Once()
+}
+
+func (f *transportFixture) purchase(t *testing.T, product *Product) *Pass {
+	f.expectCreatePass(product)
+	f.mockRepo.On("UpdatePass", mock.Anything, mock.MatchedBy(func(p *Pass) bool {
+		return p.TransactionID != nil
+	})).Return(nil).Once()
+
+	pass, err := f.service.PurchasePass(context.Background(), f.festivalID, f.userID, PurchaseRequest{
+		ProductID:    product.ID,
+		WalletID:     f.walletID,
+		LicensePlate: "ab-123 cd",
+	})
+	require.NoError(t, err)
+	return pass
+}
+
+func TestService_PurchasePass_ChargesWallet(t *testing.T) {
+	f := newTransportFixture(5000)
+	product := f.newProduct(t, ProductKindParking, 2, false)
+	assert.Equal(t, "P2 North", product.Name)
+
+	pass := f.purchase(t, product)
+	assert.Equal(t, PassStatusValid, pass.Status)
+	assert.Equal(t, int64(1500), pass.Amount)
+	assert.Equal(t, "AB123CD", pass.LicensePlate)
+	assert.Regexp(t, `^PK-[A-Z2-9]{8}$`, pass.Code)
+	require.NotNil(t, pass.TransactionID)
+	assert.Equal(t, pass.ID, *pass.TransactionID, "charged under the pass ID")
+	assert.Equal(t, int64(3500), f.wallets.wallets[f.walletID].Balance)
+
+	f.purchase(t, product)
+	f.mockRepo.On("CreatePass", mock.Anything, mock.AnythingOfType("*transport.Pass")).Return(ErrSoldOut).Once()
+	_, err := f.service.PurchasePass(context.Background(), f.festivalID, f.userID, PurchaseRequest{ProductID: product.ID, WalletID: f.walletID})
+	assert.ErrorIs(t, err, ErrSoldOut)
+	assert.Equal(t, int64(2000), f.wallets.wallets[f.walletID].Balance)
+
+	_, err = f.service.UpdateProduct(context.Background(), f.festivalID, product.ID, UpdateProductRequest{Capacity: new(int)})
+	assert.ErrorIs(t, err, ErrCapacityBelowSold)
+	f.mockRepo.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_PurchasePass_CancelledWhenChargeFails(t *testing.T) {
+	f := newTransportFixture(1000)
+	ctx := context.Background()
+	product := f.newProduct(t, ProductKindShuttle, 1, true)
+
+	f.expectCreatePass(product)
+	f.mockRepo.On("CancelPass", mock.Anything, mock.MatchedBy(func(p *Pass) bool {
+		return p.Status == PassStatusCancelled && p.Amount == 0
+	})).Run(func(args mock.Arguments) { product.Sold-- }).Return(nil).Once()
+
+	_, err := f.service.PurchasePass(ctx, f.festivalID, f.userID, PurchaseRequest{ProductID: product.ID, WalletID: f.walletID})
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.Zero(t, product.Sold, "place given back")
+	assert.Equal(t, int64(1000), f.wallets.wallets[f.walletID].Balance)
+	f.mockRepo.AssertNotCalled(t, "UpdatePass", mock.Anything, mock.Anything)
+
+	_, err = f.service.PurchasePass(ctx, f.festivalID, uuid.New(), PurchaseRequest{ProductID: product.ID, WalletID: f.walletID})
+	assert.ErrorIs(t, err, ErrWalletNotFound, "wallet of another attendee")
+
+	f.now = product.ValidUntil
+	_, err = f.service.PurchasePass(ctx, f.festivalID, f.userID, PurchaseRequest{ProductID: product.ID, WalletID: f.walletID})
+	assert.ErrorIs(t, err, ErrProductClosed)
+	f.mockRepo.AssertNumberOfCalls(t, "CreatePass", 1)
+	f.mockRepo.AssertExpectations(t)
+}
+
+func TestService_Scan(t *testing.T) {
+	f := newTransportFixture(5000)
+	ctx := context.Background()
+	staffID := uuid.New()
+	parking := f.newProduct(t, ProductKindParking, 10, false)
+	pass := f.purchase(t, parking)
+
+	f.mockRepo.On("GetPass", mock.Anything, f.festivalID, pass.ID).Return(pass, nil)
+	f.mockRepo.On("GetPassByCode", mock.Anything, f.festivalID, pass.Code).Return(pass, nil)
+	f.mockRepo.On("GetPassByCode", mock.Anything, f.festivalID, NormalizePassCode("PK-NOTAPASS")).Return(nil, nil)
+	var scans []PassScan
+	var used []*Pass
+	f.mockRepo.On("RecordScan", mock.Anything, mock.AnythingOfType("*transport.PassScan"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			scans = append(scans, *args.Get(1).(*PassScan))
+			used = append(used, args.Get(2).(*Pass))
+		}).
+		Return(nil)
+
+	qr, err := f.service.GetPassQR(ctx, f.festivalID, f.userID, pass.ID)
+	require.NoError(t, err)
+	resp, err := f.service.Scan(ctx, f.festivalID, ScanRequest{Code: qr.Payload, ScanType: ScanTypeEntry, ProductID: &parking.ID}, &staffID)
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+	require.NotNil(t, used[0], "pass used by its first entry")
+	assert.Equal(t, PassStatusUsed, used[0].Status)
+	assert.NotNil(t, used[0].FirstUsedAt)
+
+	// The printed code is typed in any case, with or without its dash
+	printed := strings.ToLower(strings.Replace(pass.Code, "-", " ", 1))
+	resp, err = f.service.Scan(ctx, f.festivalID, ScanRequest{Code: printed, ScanType: ScanTypeExit}, &staffID)
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	resp, err = f.service.Scan(ctx, f.festivalID, ScanRequest{Code: pass.Code, ScanType: ScanTypeEntry}, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, ScanResultAlready, resp.Result, "single-use pass")
+	require.Len(t, scans, 3)
+	assert.Equal(t, ScanResultAlready, scans[2].Result)
+	assert.Nil(t, used[1])
+	assert.Nil(t, used[2])
+
+	_, err = f.service.Scan(ctx, f.festivalID, ScanRequest{Code: "PK-NOTAPASS", ScanType: ScanTypeEntry}, &staffID)
+	assert.ErrorIs(t, err, ErrPassNotFound)
+	_, err = f.service.Scan(ctx, f.festivalID, ScanRequest{Code: "a ticket QR code payload", ScanType: ScanTypeEntry}, &staffID)
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+	f.mockRepo.AssertNumberOfCalls(t, "RecordScan", 3)
+	assert.Len(t, scans, 3, "codes of no pass are left to ticket check-in")
+}
+
+func TestCheckPass(t *testing.T) {
+	productID := uuid.New()
+	product := &Product{
+		ID:         productID,
+		Name:       "Shuttle A",
+		ValidFrom:  time.Date(2026, 7, 18, 8, 0, 0, 0, time.UTC),
+		ValidUntil: time.Date(2026, 7, 19, 4, 0, 0, 0, time.UTC),
+	}
+	noon := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+	entry := ScanRequest{ScanType: ScanTypeEntry}
+	other := uuid.New()
+
+	cases := []struct {
+		name     string
+		status   PassStatus
+		multiUse bool
+		req      ScanRequest
+		now      time.Time
+		want     ScanResult
+	}{
+		{"valid", PassStatusValid, false, entry, noon, ScanResultSuccess},
+		{"reentry", PassStatusUsed, false, entry, noon, ScanResultAlready},
+		{"multi-use reentry", PassStatusUsed, true, entry, noon, ScanResultSuccess},
+		{"exit of a used pass", PassStatusUsed, false, ScanRequest{ScanType: ScanTypeExit}, noon, ScanResultSuccess},
+		{"cancelled", PassStatusCancelled, false, entry, noon, ScanResultInvalid},
+		{"other product", PassStatusValid, false, ScanRequest{ScanType: ScanTypeEntry, ProductID: &other}, noon, ScanResultInvalid},
+		{"not yet valid", PassStatusValid, false, entry, product.ValidFrom.Add(-time.Minute), ScanResultInvalid},
+		{"expired", PassStatusValid, false, entry, product.ValidUntil.Add(time.Minute), ScanResultExpired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := *product
+			p.MultiUse = tc.multiUse
+			result, _ := CheckPass(&Pass{ProductID: productID, Status: tc.status}, &p, tc.req, tc.now)
+			assert.Equal(t, tc.want, result)
+		})
+	}
+}
+
+func TestComputeUsage(t *testing.T) {
+	parking, shuttle := uuid.New(), uuid.New()
+	nine := time.Date(2026, 7, 18, 9, 0, 0, 0, time.UTC)
+	ten := nine.Add(time.Hour)
+
+	usage := ComputeUsage([]ProductUsage{
+		{ProductID: parking, Sold: 120, PassesUsed: 100},
+		{ProductID: shuttle, Sold: 40, PassesUsed: 40},
+	}, []HourlyTraffic{
+		{ProductID: parking, Hour: nine, Entries: 30},
+		{ProductID: parking, Hour: ten, Entries: 70, Exits: 5},
+		{ProductID: shuttle, Hour: ten, Exits: 12},
+	})
+
+	assert.Equal(t, int64(20), usage.Products[0].NoShows)
+	require.NotNil(t, usage.Products[0].PeakHour)
+	assert.Equal(t, ten, *usage.Products[0].PeakHour)
+	assert.Equal(t, int64(70), usage.Products[0].PeakEntries)
+	assert.Zero(t, usage.Products[1].NoShows)
+	assert.Nil(t, usage.Products[1].PeakHour, "no entries")
+	assert.Len(t, usage.Hours, 3)
+}
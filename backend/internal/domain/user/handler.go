@@ -2,7 +2,9 @@ package user
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -39,9 +41,21 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup, adminMiddleware gin.Handler
 		users.POST("/:id/ban", h.BanUser)
 		users.POST("/:id/unban", h.UnbanUser)
 		users.DELETE("/:id", h.DeleteUser)
+		users.GET("/:id/role-changes", h.ListUserRoleChanges)
 	}
 }
 
+// RegisterAdminRoutes registers the role management routes on the platform
+// administration group
+func (h *Handler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	users := r.Group("/users")
+	{
+		users.PATCH("/:id/role", h.UpdateUserRole)
+		users.GET("/:id/role-changes", h.ListUserRoleChanges)
+	}
+	r.GET("/role-changes", h.ListRoleChanges)
+}
+
 // GetCurrentUser returns the currently authenticated user's profile
 // @Summary Get current user profile
 // @Tags users
@@ -99,7 +113,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 
 	var req UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -198,7 +212,10 @@ func (h *Handler) GetUser(c *gin.Context) {
 	response.OK(c, user.ToResponse())
 }
 
-// UpdateUserRole updates a user's role (admin only)
+// UpdateUserRole updates a user's role (admin only). Elevating a user to ADMIN or
+// ORGANIZER requires a token issued after multi-factor authentication in the last
+// minutes; otherwise the request fails with MFA_REQUIRED and the client must
+// re-authenticate.
 // @Summary Update user role
 // @Tags users
 // @Accept json
@@ -206,6 +223,9 @@ func (h *Handler) GetUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param request body UpdateUserRoleRequest true "Role update data"
 // @Success 200 {object} UserResponse
+// @Failure 401 {object} response.ErrorResponse "Multi-factor authentication required"
+// @Failure 403 {object} response.ErrorResponse "Own role"
+// @Failure 409 {object} response.ErrorResponse "Role unchanged"
 // @Router /users/{id}/role [patch]
 func (h *Handler) UpdateUserRole(c *gin.Context) {
 	idStr := c.Param("id")
@@ -217,7 +237,7 @@ func (h *Handler) UpdateUserRole(c *gin.Context) {
 
 	var req UpdateUserRoleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -226,23 +246,116 @@ func (h *Handler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.UpdateRole(c.Request.Context(), id, req.Role)
+	actor, err := h.actor(c)
 	if err != nil {
-		if err == errors.ErrNotFound {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	user, err := h.service.ChangeRole(c.Request.Context(), id, req, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
 			response.NotFound(c, "User not found")
-			return
-		}
-		if err == errors.ErrValidation {
+		case errors.Is(err, errors.ErrValidation):
 			response.BadRequest(c, "INVALID_ROLE", "Invalid role specified", nil)
-			return
+		case errors.Is(err, ErrMFARequired):
+			response.SendError(c, response.NewStandardError(http.StatusUnauthorized, errors.ErrCodeMFARequired,
+				"Confirm the role change with multi-factor authentication", gin.H{"maxAgeSeconds": int(StepUpMaxAge.Seconds())}))
+		case errors.Is(err, ErrSelfRoleChange):
+			response.Forbidden(c, "You cannot change your own role")
+		case errors.Is(err, ErrRoleUnchanged):
+			response.Conflict(c, "ROLE_UNCHANGED", "User already has this role")
+		default:
+			response.InternalError(c, err.Error())
 		}
-		response.InternalError(c, err.Error())
 		return
 	}
 
 	response.OK(c, user.ToResponse())
 }
 
+// ListRoleChanges returns the role changes of every user (admin only)
+// @Summary List role changes
+// @Tags users
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Param role query string false "Changes granting or removing the role"
+// @Success 200 {array} RoleChange
+// @Router /admin/role-changes [get]
+func (h *Handler) ListRoleChanges(c *gin.Context) {
+	h.listRoleChanges(c, nil)
+}
+
+// ListUserRoleChanges returns the role changes of a user (admin only)
+// @Summary List the role changes of a user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {array} RoleChange
+// @Router /users/{id}/role-changes [get]
+func (h *Handler) ListUserRoleChanges(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid user ID", nil)
+		return
+	}
+	h.listRoleChanges(c, &id)
+}
+
+func (h *Handler) listRoleChanges(c *gin.Context, userID *uuid.UUID) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	filter := RoleChangeFilter{UserID: userID, Role: UserRole(c.Query("role"))}
+	if filter.Role != "" && !filter.Role.IsValid() {
+		response.BadRequest(c, "INVALID_ROLE", "Invalid role filter", nil)
+		return
+	}
+
+	changes, total, err := h.service.ListRoleChanges(c.Request.Context(), filter, page, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, changes, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// actor returns the administrator making the request. The auth middleware sets the
+// authentication methods of the token and the time of its last second factor.
+func (h *Handler) actor(c *gin.Context) (Actor, error) {
+	actor := Actor{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		RequestID: c.GetString("request_id"),
+	}
+
+	if subject := c.GetString("user_id"); subject != "" {
+		user, err := h.service.ResolveActor(c.Request.Context(), subject)
+		if err != nil {
+			return actor, err
+		}
+		actor.User = user
+	}
+
+	if slices.Contains(c.GetStringSlice("amr"), "mfa") {
+		if mfaTime := c.GetInt64("mfa_time"); mfaTime > 0 {
+			mfaAt := time.Unix(mfaTime, 0)
+			actor.MFAAt = &mfaAt
+		}
+	}
+
+	return actor, nil
+}
+
 // BanUser bans a user (admin only)
 // @Summary Ban user
 // @Tags users
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -43,6 +44,44 @@ func (r UserRole) IsValid() bool {
 	return false
 }
 
+// IsPrivileged reports whether the role administers festivals or the platform
+func (r UserRole) IsPrivileged() bool {
+	return r == UserRoleAdmin || r == UserRoleOrganizer
+}
+
+// rank orders the roles by the access they grant
+func (r UserRole) rank() int {
+	switch r {
+	case UserRoleAdmin:
+		return 3
+	case UserRoleOrganizer:
+		return 2
+	case UserRoleStaff:
+		return 1
+	}
+	return 0
+}
+
+// IsElevation reports whether changing from one role to another grants a privileged
+// role with more access
+func IsElevation(from, to UserRole) bool {
+	return to.IsPrivileged() && to.rank() > from.rank()
+}
+
+// StepUpMaxAge is how recently an administrator must have completed multi-factor
+// authentication to elevate the role of a user
+const StepUpMaxAge = 10 * time.Minute
+
+// maxAdminNotices caps the administrators told about a privileged role change
+const maxAdminNotices = 100
+
+var (
+	ErrMFARequired    = errors.New("multi-factor authentication required to elevate a role")
+	ErrSelfRoleChange = errors.New("administrators cannot change their own role")
+	ErrRoleUnchanged  = errors.New("user already has this role")
+	ErrUserBanned     = errors.New("user is banned")
+)
+
 // UserStatus represents the status of a user
 type UserStatus string
 
@@ -72,6 +111,20 @@ type Auth0Profile struct {
 	UpdatedAt     string `json:"updated_at"`
 }
 
+// SSOProfile is a user signed in through the OIDC provider of their organization, with
+// the role mapped from their groups at the provider
+type SSOProfile struct {
+	Subject    string // "oidc|<connection ID>|<subject at the provider>"
+	Email      string
+	Name       string
+	Role       UserRole
+	Connection string // Name of the SSO connection, recorded on role changes
+}
+
+// EmailSubjectPrefix prefixes the subject of the users created by signing in with a
+// magic link, followed by their ID
+const EmailSubjectPrefix = "email|"
+
 // CreateUserRequest represents the request to create a user
 type CreateUserRequest struct {
 	Email   string   `json:"email" binding:"required,email"`
@@ -91,7 +144,8 @@ type UpdateUserRequest struct {
 
 // UpdateUserRoleRequest represents the request to update a user's role
 type UpdateUserRoleRequest struct {
-	Role UserRole `json:"role" binding:"required"`
+	Role   UserRole `json:"role" binding:"required"`
+	Reason string   `json:"reason,omitempty"`
 }
 
 // BanUserRequest represents the request to ban a user
@@ -134,3 +188,50 @@ type UserListResponse struct {
 	Page  int            `json:"page"`
 	Limit int            `json:"limit"`
 }
+
+// RoleChange records a change of the platform role of a user
+type RoleChange struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"userId" gorm:"type:uuid;not null"`
+	ActorID     *uuid.UUID `json:"actorId,omitempty" gorm:"type:uuid"`
+	OldRole     UserRole   `json:"oldRole" gorm:"not null"`
+	NewRole     UserRole   `json:"newRole" gorm:"not null"`
+	Reason      string     `json:"reason,omitempty"`
+	MFAVerified bool       `json:"mfaVerified" gorm:"column:mfa_verified"`
+	IPAddress   string     `json:"ipAddress,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func (RoleChange) TableName() string {
+	return "public.user_role_changes"
+}
+
+// RoleChangeFilter narrows the role change history
+type RoleChangeFilter struct {
+	UserID *uuid.UUID
+	Role   UserRole // Changes granting or removing the role
+}
+
+// Actor is the administrator changing a role, with the context of the request
+type Actor struct {
+	User      *User
+	MFAAt     *time.Time // Last multi-factor authentication, from the token claims
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+// RoleChangeNotice tells a user their role changed, or an administrator that the role
+// of another user changed
+type RoleChangeNotice struct {
+	To        string
+	Locale    string
+	Admin     bool // Sent to an administrator rather than the user
+	UserName  string
+	UserEmail string
+	ActorName string
+	OldRole   UserRole
+	NewRole   UserRole
+	Reason    string
+	ChangedAt time.Time
+}
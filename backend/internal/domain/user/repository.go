@@ -21,6 +21,9 @@ type Repository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	ExistsByAuth0ID(ctx context.Context, auth0ID string) (bool, error)
+	ChangeRole(ctx context.Context, user *User, change *RoleChange) error
+	ListRoleChanges(ctx context.Context, filter RoleChangeFilter, offset, limit int) ([]RoleChange, int64, error)
+	GetPreferredLanguage(ctx context.Context, userID uuid.UUID) (string, error)
 }
 
 type repository struct {
@@ -150,3 +153,59 @@ func (r *repository) ExistsByAuth0ID(ctx context.Context, auth0ID string) (bool,
 	}
 	return count > 0, nil
 }
+
+// ChangeRole updates the role of the user and records the change in one transaction
+func (r *repository) ChangeRole(ctx context.Context, user *User, change *RoleChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"role": user.Role, "updated_at": user.UpdatedAt}).Error; err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return fmt.Errorf("failed to record role change: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) ListRoleChanges(ctx context.Context, filter RoleChangeFilter, offset, limit int) ([]RoleChange, int64, error) {
+	var changes []RoleChange
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&RoleChange{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Role != "" {
+		query = query.Where("old_role = ? OR new_role = ?", filter.Role, filter.Role)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count role changes: %w", err)
+	}
+
+	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&changes).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list role changes: %w", err)
+	}
+
+	return changes, total, nil
+}
+
+// GetPreferredLanguage returns the language the user reads their notifications in,
+// empty when they never set one
+func (r *repository) GetPreferredLanguage(ctx context.Context, userID uuid.UUID) (string, error) {
+	var languages []string
+	err := r.db.WithContext(ctx).
+		Table("public.user_notification_preferences").
+		Select("preferred_language").
+		Where("user_id = ?", userID).
+		Limit(1).
+		Scan(&languages).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to get preferred language: %w", err)
+	}
+	if len(languages) == 0 {
+		return "", nil
+	}
+	return languages[0], nil
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) Create(ctx context.Context, user *User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockRepository) GetByAuth0ID(ctx context.Context, auth0ID string) (*User, error) {
+	args := m.Called(ctx, auth0ID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context, offset, limit int) ([]User, int64, error) {
+	args := m.Called(ctx, offset, limit)
+	return args.Get(0).([]User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) ListByRole(ctx context.Context, role UserRole, offset, limit int) ([]User, int64, error) {
+	args := m.Called(ctx, role, offset, limit)
+	return args.Get(0).([]User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) Search(ctx context.Context, query string, offset, limit int) ([]User, int64, error) {
+	args := m.Called(ctx, query, offset, limit)
+	return args.Get(0).([]User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) Update(ctx context.Context, user *User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ExistsByAuth0ID(ctx context.Context, auth0ID string) (bool, error) {
+	args := m.Called(ctx, auth0ID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ChangeRole(ctx context.Context, user *User, change *RoleChange) error {
+	args := m.Called(ctx, user, change)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListRoleChanges(ctx context.Context, filter RoleChangeFilter, offset, limit int) ([]RoleChange, int64, error) {
+	args := m.Called(ctx, filter, offset, limit)
+	return args.Get(0).([]RoleChange), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetPreferredLanguage(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
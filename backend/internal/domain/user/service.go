@@ -3,10 +3,13 @@ package user
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/rs/zerolog/log"
 )
 
 // getOrNotFound is a helper that wraps repository lookups with nil-check pattern
@@ -20,14 +23,38 @@ func getOrNotFound[T any](result *T, err error) (*T, error) {
 	return result, nil
 }
 
+// Notifier emails users and administrators about role changes; satisfied by
+// jobs.EmailQueue
+type Notifier interface {
+	NotifyRoleChange(ctx context.Context, notice RoleChangeNotice) error
+}
+
+// Auditor records security events; satisfied by security.SecurityAuditor
+type Auditor interface {
+	LogEvent(ctx context.Context, event *security.SecurityEvent)
+}
+
 // Service handles user business logic
 type Service struct {
-	repo Repository
+	repo     Repository
+	notifier Notifier
+	auditor  Auditor
+	now      func() time.Time
 }
 
 // NewService creates a new user service
 func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+	return &Service{repo: repo, now: time.Now}
+}
+
+// SetNotifier notifies the users and administrators of role changes
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// SetAuditor records role changes as security events
+func (s *Service) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
 }
 
 // GetOrCreateFromAuth0 retrieves a user by Auth0 ID or creates a new one from the Auth0 profile
@@ -113,6 +140,108 @@ func (s *Service) GetOrCreateFromAuth0(ctx context.Context, profile Auth0Profile
 	return newUser, nil
 }
 
+// ProvisionFromSSO retrieves the user signed in through an SSO connection, by subject
+// then by email, or creates them on their first sign-in. The provider is the source of
+// truth for the role: a different mapped role is recorded as a role change without
+// actor, except for administrators who keep theirs. It reports whether the user was
+// created.
+func (s *Service) ProvisionFromSSO(ctx context.Context, profile SSOProfile) (*User, bool, error) {
+	user, err := s.repo.GetByAuth0ID(ctx, profile.Subject)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lookup user by SSO subject: %w", err)
+	}
+	if user == nil {
+		if user, err = s.repo.GetByEmail(ctx, profile.Email); err != nil {
+			return nil, false, fmt.Errorf("failed to lookup user by email: %w", err)
+		}
+	}
+
+	now := s.now()
+	if user == nil {
+		name := profile.Name
+		if name == "" {
+			name = profile.Email
+		}
+		user = &User{
+			ID:        uuid.New(),
+			Email:     profile.Email,
+			Name:      name,
+			Role:      profile.Role,
+			Auth0ID:   profile.Subject,
+			Status:    UserStatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.repo.Create(ctx, user); err != nil {
+			return nil, false, fmt.Errorf("failed to create user from SSO profile: %w", err)
+		}
+		return user, true, nil
+	}
+
+	if user.Status == UserStatusBanned {
+		return nil, false, ErrUserBanned
+	}
+
+	if profile.Name != "" && user.Name != profile.Name {
+		user.Name = profile.Name
+		user.UpdatedAt = now
+		if err := s.repo.Update(ctx, user); err != nil {
+			return nil, false, fmt.Errorf("failed to update user from SSO profile: %w", err)
+		}
+	}
+
+	if user.Role != profile.Role && user.Role != UserRoleAdmin {
+		change := &RoleChange{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			OldRole:   user.Role,
+			NewRole:   profile.Role,
+			Reason:    "Mapped from the groups of SSO connection " + profile.Connection,
+			CreatedAt: now,
+		}
+		user.Role = profile.Role
+		user.UpdatedAt = now
+		if err := s.repo.ChangeRole(ctx, user, change); err != nil {
+			return nil, false, err
+		}
+		s.audit(ctx, security.EventAuthzRoleChange, security.SeverityInfo, "success", change, Actor{})
+	}
+	return user, false, nil
+}
+
+// ProvisionFromEmail retrieves the user owning an email, or creates an attendee for it
+// on their first sign-in with a magic link, which proved they own it. It reports
+// whether the user was created.
+func (s *Service) ProvisionFromEmail(ctx context.Context, email string) (*User, bool, error) {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lookup user by email: %w", err)
+	}
+	if user != nil {
+		if user.Status == UserStatusBanned {
+			return nil, false, ErrUserBanned
+		}
+		return user, false, nil
+	}
+
+	now := s.now()
+	id := uuid.New()
+	user = &User{
+		ID:        id,
+		Email:     email,
+		Name:      strings.SplitN(email, "@", 2)[0],
+		Role:      UserRoleUser,
+		Auth0ID:   EmailSubjectPrefix + id.String(),
+		Status:    UserStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, false, fmt.Errorf("failed to create user from email: %w", err)
+	}
+	return user, true, nil
+}
+
 // GetByID retrieves a user by ID
 func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	return getOrNotFound(s.repo.GetByID(ctx, id))
@@ -215,6 +344,165 @@ func (s *Service) UpdateRole(ctx context.Context, id uuid.UUID, role UserRole) (
 	return user, nil
 }
 
+// ChangeRole changes the role of a user on behalf of an administrator. Elevating a user
+// to ADMIN or ORGANIZER requires the administrator to have completed multi-factor
+// authentication within StepUpMaxAge. The change is recorded and audited, the user is
+// notified and so are the other administrators when a privileged role is granted or
+// removed.
+func (s *Service) ChangeRole(ctx context.Context, id uuid.UUID, req UpdateUserRoleRequest, actor Actor) (*User, error) {
+	if !req.Role.IsValid() {
+		return nil, errors.ErrValidation
+	}
+
+	user, err := getOrNotFound(s.repo.GetByID(ctx, id))
+	if err != nil {
+		return nil, err
+	}
+	if actor.User != nil && actor.User.ID == user.ID {
+		return nil, ErrSelfRoleChange
+	}
+	if user.Role == req.Role {
+		return nil, ErrRoleUnchanged
+	}
+
+	now := s.now()
+	change := &RoleChange{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		OldRole:     user.Role,
+		NewRole:     req.Role,
+		Reason:      req.Reason,
+		MFAVerified: actor.MFAAt != nil && now.Sub(*actor.MFAAt) <= StepUpMaxAge,
+		IPAddress:   actor.IPAddress,
+		CreatedAt:   now,
+	}
+	if actor.User != nil {
+		change.ActorID = &actor.User.ID
+	}
+	if IsElevation(change.OldRole, change.NewRole) && !change.MFAVerified {
+		s.audit(ctx, security.EventAuthzElevation, security.SeverityWarning, "denied", change, actor)
+		return nil, ErrMFARequired
+	}
+
+	user.Role = req.Role
+	user.UpdatedAt = now
+	if err := s.repo.ChangeRole(ctx, user, change); err != nil {
+		return nil, err
+	}
+
+	severity := security.SeverityInfo
+	if change.NewRole == UserRoleAdmin || change.OldRole == UserRoleAdmin {
+		severity = security.SeverityWarning
+	}
+	s.audit(ctx, security.EventAuthzRoleChange, severity, "success", change, actor)
+	s.notifyRoleChange(ctx, user, change, actor)
+
+	return user, nil
+}
+
+// ListRoleChanges returns the role change history, newest first
+func (s *Service) ListRoleChanges(ctx context.Context, filter RoleChangeFilter, page, perPage int) ([]RoleChange, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	offset := (page - 1) * perPage
+	return s.repo.ListRoleChanges(ctx, filter, offset, perPage)
+}
+
+// ResolveActor finds the user behind the subject of an access token, an Auth0 ID or,
+// for tokens issued by the API, a user ID
+func (s *Service) ResolveActor(ctx context.Context, subject string) (*User, error) {
+	if id, err := uuid.Parse(subject); err == nil {
+		return s.repo.GetByID(ctx, id)
+	}
+	return s.repo.GetByAuth0ID(ctx, subject)
+}
+
+func (s *Service) audit(ctx context.Context, eventType security.SecurityEventType, severity security.SecurityEventSeverity, result string, change *RoleChange, actor Actor) {
+	if s.auditor == nil {
+		return
+	}
+
+	event := &security.SecurityEvent{
+		Timestamp: s.now(),
+		Type:      eventType,
+		Severity:  severity,
+		RequestID: actor.RequestID,
+		IPAddress: actor.IPAddress,
+		UserAgent: actor.UserAgent,
+		Resource:  "user:" + change.UserID.String(),
+		Action:    "role_change",
+		Result:    result,
+		Details: map[string]interface{}{
+			"target_user_id": change.UserID.String(),
+			"old_role":       string(change.OldRole),
+			"new_role":       string(change.NewRole),
+			"mfa_verified":   change.MFAVerified,
+			"reason":         change.Reason,
+		},
+	}
+	if actor.User != nil {
+		event.UserID = actor.User.ID.String()
+	}
+	s.auditor.LogEvent(ctx, event)
+}
+
+// notifyRoleChange emails the user and, when a privileged role is granted or removed,
+// the other administrators. Failures are logged: the change is already made.
+func (s *Service) notifyRoleChange(ctx context.Context, user *User, change *RoleChange, actor Actor) {
+	if s.notifier == nil {
+		return
+	}
+
+	notice := RoleChangeNotice{
+		UserName:  user.Name,
+		UserEmail: user.Email,
+		OldRole:   change.OldRole,
+		NewRole:   change.NewRole,
+		Reason:    change.Reason,
+		ChangedAt: change.CreatedAt,
+	}
+	if actor.User != nil {
+		notice.ActorName = actor.User.Name
+	}
+
+	s.sendRoleChangeNotice(ctx, user, notice)
+
+	if !change.OldRole.IsPrivileged() && !change.NewRole.IsPrivileged() {
+		return
+	}
+	admins, _, err := s.repo.ListByRole(ctx, UserRoleAdmin, 0, maxAdminNotices)
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", user.ID.String()).Msg("Failed to list administrators to notify of role change")
+		return
+	}
+	notice.Admin = true
+	for i := range admins {
+		admin := &admins[i]
+		if admin.ID == user.ID || (actor.User != nil && admin.ID == actor.User.ID) {
+			continue
+		}
+		s.sendRoleChangeNotice(ctx, admin, notice)
+	}
+}
+
+func (s *Service) sendRoleChangeNotice(ctx context.Context, recipient *User, notice RoleChangeNotice) {
+	locale, err := s.repo.GetPreferredLanguage(ctx, recipient.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", recipient.ID.String()).Msg("Failed to load preferred language")
+	}
+	notice.To = recipient.Email
+	notice.Locale = locale
+
+	if err := s.notifier.NotifyRoleChange(ctx, notice); err != nil {
+		log.Warn().Err(err).Str("user_id", recipient.ID.String()).Msg("Failed to notify role change")
+	}
+}
+
 // BanUser bans a user (admin function)
 func (s *Service) BanUser(ctx context.Context, id uuid.UUID, reason string) (*User, error) {
 	user, err := getOrNotFound(s.repo.GetByID(ctx, id))
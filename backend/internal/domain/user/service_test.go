@@ -0,0 +1,235 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testUser(name string, role UserRole) *User {
+	return &User{ID: uuid.New(), Name: name, Email: name + "@festivals.test", Role: role, Auth0ID: "auth0|" + name}
+}
+
+// expectUsers serves the users by ID. The service updates the returned users in
+// place, so role changes are kept across calls.
+func expectUsers(mockRepo *MockRepository, users ...*User) {
+	for _, user := range users {
+		mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	}
+	mockRepo.On("GetPreferredLanguage", mock.Anything, mock.Anything).Return("", nil).Maybe()
+}
+
+// expectRoleChanges records the role changes saved by the service
+func expectRoleChanges(mockRepo *MockRepository) *[]RoleChange {
+	changes := &[]RoleChange{}
+	mockRepo.On("ChangeRole", mock.Anything, mock.AnythingOfType("*user.User"), mock.AnythingOfType("*user.RoleChange")).
+		Run(func(args mock.Arguments) { *changes = append(*changes, *args.Get(2).(*RoleChange)) }).
+		Return(nil)
+	return changes
+}
+
+type fakeNotifier struct {
+	notices []RoleChangeNotice
+}
+
+func (n *fakeNotifier) NotifyRoleChange(ctx context.Context, notice RoleChangeNotice) error {
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+type fakeAuditor struct {
+	events []*security.SecurityEvent
+}
+
+func (a *fakeAuditor) LogEvent(ctx context.Context, event *security.SecurityEvent) {
+	a.events = append(a.events, event)
+}
+
+var testNow = time.Date(2026, 7, 18, 15, 0, 0, 0, time.UTC)
+
+func newTestService(repo Repository) (*Service, *fakeNotifier, *fakeAuditor) {
+	notifier := &fakeNotifier{}
+	auditor := &fakeAuditor{}
+	service := NewService(repo)
+	service.SetNotifier(notifier)
+	service.SetAuditor(auditor)
+	service.now = func() time.Time { return testNow }
+	return service, notifier, auditor
+}
+
+func TestService_ChangeRoleRequiresMFAForElevation(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _, auditor := newTestService(mockRepo)
+	admin := testUser("alice", UserRoleAdmin)
+	staff := testUser("bob", UserRoleStaff)
+	ctx := context.Background()
+	expectUsers(mockRepo, admin, staff)
+	changes := expectRoleChanges(mockRepo)
+	mockRepo.On("ListByRole", mock.Anything, UserRoleAdmin, 0, maxAdminNotices).Return([]User{*admin}, int64(1), nil)
+
+	_, err := service.ChangeRole(ctx, staff.ID, UpdateUserRoleRequest{Role: UserRoleOrganizer}, Actor{User: admin})
+	assert.ErrorIs(t, err, ErrMFARequired)
+
+	// A second factor from an earlier session is not enough
+	stale := testNow.Add(-StepUpMaxAge - time.Minute)
+	_, err = service.ChangeRole(ctx, staff.ID, UpdateUserRoleRequest{Role: UserRoleOrganizer}, Actor{User: admin, MFAAt: &stale})
+	assert.ErrorIs(t, err, ErrMFARequired)
+	assert.Equal(t, UserRoleStaff, staff.Role)
+	assert.Empty(t, *changes)
+	require.Len(t, auditor.events, 2)
+	assert.Equal(t, security.EventAuthzElevation, auditor.events[0].Type)
+	assert.Equal(t, "denied", auditor.events[0].Result)
+
+	// Demotions do not need one
+	_, err = service.ChangeRole(ctx, staff.ID, UpdateUserRoleRequest{Role: UserRoleUser}, Actor{User: admin})
+	require.NoError(t, err)
+
+	recent := testNow.Add(-time.Minute)
+	updated, err := service.ChangeRole(ctx, staff.ID, UpdateUserRoleRequest{Role: UserRoleOrganizer, Reason: "Runs the main stage"}, Actor{User: admin, MFAAt: &recent})
+	require.NoError(t, err)
+	assert.Equal(t, UserRoleOrganizer, updated.Role)
+	require.Len(t, *changes, 2)
+	assert.True(t, (*changes)[1].MFAVerified)
+	assert.Equal(t, &admin.ID, (*changes)[1].ActorID)
+	assert.Equal(t, security.EventAuthzRoleChange, auditor.events[len(auditor.events)-1].Type)
+
+	_, err = service.ChangeRole(ctx, admin.ID, UpdateUserRoleRequest{Role: UserRoleUser}, Actor{User: admin})
+	assert.ErrorIs(t, err, ErrSelfRoleChange)
+
+	_, err = service.ChangeRole(ctx, staff.ID, UpdateUserRoleRequest{Role: UserRoleOrganizer}, Actor{User: admin, MFAAt: &recent})
+	assert.ErrorIs(t, err, ErrRoleUnchanged)
+	assert.Len(t, *changes, 2)
+}
+
+func TestService_ChangeRoleNotifiesAdmins(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, notifier, _ := newTestService(mockRepo)
+	actor := testUser("alice", UserRoleAdmin)
+	other := testUser("carol", UserRoleAdmin)
+	target := testUser("bob", UserRoleUser)
+	staff := testUser("dave", UserRoleUser)
+	ctx := context.Background()
+	expectUsers(mockRepo, actor, other, target, staff)
+	expectRoleChanges(mockRepo)
+	mockRepo.On("ListByRole", mock.Anything, UserRoleAdmin, 0, maxAdminNotices).
+		Return([]User{*actor, *other, *target}, int64(3), nil).Once()
+
+	recent := testNow.Add(-time.Minute)
+	_, err := service.ChangeRole(ctx, target.ID, UpdateUserRoleRequest{Role: UserRoleAdmin}, Actor{User: actor, MFAAt: &recent})
+	require.NoError(t, err)
+
+	// The user and the other administrator, not the one who made the change
+	require.Len(t, notifier.notices, 2)
+	assert.Equal(t, target.Email, notifier.notices[0].To)
+	assert.False(t, notifier.notices[0].Admin)
+	assert.Equal(t, other.Email, notifier.notices[1].To)
+	assert.True(t, notifier.notices[1].Admin)
+	assert.Equal(t, "alice", notifier.notices[1].ActorName)
+	assert.Equal(t, UserRoleAdmin, notifier.notices[1].NewRole)
+
+	// Non-privileged changes only reach the user
+	notifier.notices = nil
+	_, err = service.ChangeRole(ctx, staff.ID, UpdateUserRoleRequest{Role: UserRoleStaff}, Actor{User: actor})
+	require.NoError(t, err)
+	require.Len(t, notifier.notices, 1)
+	assert.Equal(t, staff.Email, notifier.notices[0].To)
+	mockRepo.AssertNumberOfCalls(t, "ListByRole", 1)
+}
+
+func TestService_ProvisionFromSSO(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo)
+	ctx := context.Background()
+	changes := expectRoleChanges(mockRepo)
+
+	profile := SSOProfile{
+		Subject:    "oidc|conn|00u1",
+		Email:      "jane@acme.test",
+		Name:       "Jane",
+		Role:       UserRoleOrganizer,
+		Connection: "Acme",
+	}
+	stored := &User{}
+	mockRepo.On("GetByAuth0ID", mock.Anything, profile.Subject).Return(nil, nil).Once()
+	mockRepo.On("GetByEmail", mock.Anything, profile.Email).Return(nil, nil).Once()
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*user.User")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*User) }).
+		Return(nil).Once()
+	created, isNew, err := service.ProvisionFromSSO(ctx, profile)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, UserRoleOrganizer, created.Role)
+	assert.Equal(t, profile.Subject, created.Auth0ID)
+
+	// The provider is the source of truth for the role
+	profile.Role = UserRoleStaff
+	mockRepo.On("GetByAuth0ID", mock.Anything, profile.Subject).Return(stored, nil).Once()
+	updated, isNew, err := service.ProvisionFromSSO(ctx, profile)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, UserRoleStaff, updated.Role)
+	require.Len(t, *changes, 1)
+	assert.Nil(t, (*changes)[0].ActorID)
+	assert.Contains(t, (*changes)[0].Reason, "Acme")
+
+	// Existing accounts are matched by email and keep their subject; admins keep their role
+	admin := testUser("admin", UserRoleAdmin)
+	mockRepo.On("GetByAuth0ID", mock.Anything, "oidc|conn|00u2").Return(nil, nil).Once()
+	mockRepo.On("GetByEmail", mock.Anything, admin.Email).Return(admin, nil).Once()
+	found, isNew, err := service.ProvisionFromSSO(ctx, SSOProfile{Subject: "oidc|conn|00u2", Email: admin.Email, Role: UserRoleStaff})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, admin.ID, found.ID)
+	assert.Equal(t, "auth0|admin", found.Auth0ID)
+	assert.Equal(t, UserRoleAdmin, found.Role)
+
+	banned := testUser("banned", UserRoleUser)
+	banned.Status = UserStatusBanned
+	mockRepo.On("GetByAuth0ID", mock.Anything, "oidc|conn|00u3").Return(nil, nil).Once()
+	mockRepo.On("GetByEmail", mock.Anything, banned.Email).Return(banned, nil).Once()
+	_, _, err = service.ProvisionFromSSO(ctx, SSOProfile{Subject: "oidc|conn|00u3", Email: banned.Email, Role: UserRoleUser})
+	assert.ErrorIs(t, err, ErrUserBanned)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	assert.Len(t, *changes, 1)
+}
+
+func TestService_ProvisionFromEmail(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	stored := &User{}
+	mockRepo.On("GetByEmail", mock.Anything, "sam@example.test").Return(nil, nil).Once()
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*user.User")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*User) }).
+		Return(nil).Once()
+	created, isNew, err := service.ProvisionFromEmail(ctx, "sam@example.test")
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, UserRoleUser, created.Role)
+	assert.Equal(t, "sam", created.Name)
+	assert.Equal(t, EmailSubjectPrefix+created.ID.String(), created.Auth0ID)
+
+	mockRepo.On("GetByEmail", mock.Anything, "sam@example.test").Return(stored, nil).Once()
+	found, isNew, err := service.ProvisionFromEmail(ctx, "sam@example.test")
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, created.ID, found.ID)
+
+	banned := testUser("banned", UserRoleUser)
+	banned.Status = UserStatusBanned
+	mockRepo.On("GetByEmail", mock.Anything, banned.Email).Return(banned, nil).Once()
+	_, _, err = service.ProvisionFromEmail(ctx, banned.Email)
+	assert.ErrorIs(t, err, ErrUserBanned)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,1245 @@
+package vendorportal
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped routes organizers manage vendors with
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	owners := r.Group("/vendors")
+	{
+		owners.GET("", h.ListOwners)
+		owners.POST("", h.AssignOwner)
+		owners.DELETE("/:ownerId", h.RemoveOwner)
+	}
+
+	payouts := r.Group("/vendor-payouts")
+	{
+		payouts.GET("", h.ListPayouts)
+		payouts.POST("", h.CreatePayout)
+		payouts.PATCH("/:payoutId", h.UpdatePayout)
+	}
+
+	rules := r.Group("/commission-rules")
+	{
+		rules.GET("", h.ListCommissionRules)
+		rules.POST("", h.CreateCommissionRule)
+		rules.DELETE("/:ruleId", h.DeleteCommissionRule)
+	}
+
+	adjustments := r.Group("/settlement-adjustments")
+	{
+		adjustments.GET("", h.ListAdjustments)
+		adjustments.POST("", h.CreateAdjustment)
+	}
+
+	r.GET("/vendor-profitability", h.GetProfitability)
+
+	menuChanges := r.Group("/menu-changes")
+	{
+		menuChanges.GET("", h.ListMenuChanges)
+		menuChanges.POST("/:changeId/approve", h.ApproveMenuChange)
+		menuChanges.POST("/:changeId/reject", h.RejectMenuChange)
+	}
+}
+
+// RegisterPortalRoutes registers the vendor portal routes. ownership must reject
+// callers who do not own the :standId stand.
+func (h *Handler) RegisterPortalRoutes(r *gin.RouterGroup, ownership gin.HandlerFunc) {
+	r.GET("/stands", h.ListMyStands)
+
+	stands := r.Group("/stands/:standId")
+	stands.Use(ownership)
+	{
+		stands.GET("", h.GetStand)
+		stands.GET("/products", h.ListProducts)
+		stands.POST("/products", h.CreateProduct)
+		stands.PATCH("/products/:productId", h.UpdateProduct)
+		stands.DELETE("/products/:productId", h.DeleteProduct)
+		stands.GET("/menu-changes", h.ListStandMenuChanges)
+		stands.POST("/menu-changes/:changeId/submit", h.SubmitMenuChange)
+		stands.DELETE("/menu-changes/:changeId", h.DiscardMenuChange)
+		stands.GET("/sales/live", h.GetLiveSales)
+		stands.GET("/statement", h.GetStatement)
+		stands.GET("/payouts", h.GetPayouts)
+		stands.GET("/staff", h.ListStaff)
+		stands.POST("/staff", h.AssignStaff)
+		stands.DELETE("/staff/:userId", h.RemoveStaff)
+	}
+}
+
+// ListOwners lists the stand owners of the festival
+// @Summary List stand owners
+// @Description List the vendor accounts owning stands of the festival
+// @Tags vendors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]StandOwner} "Stand owners"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendors [get]
+func (h *Handler) ListOwners(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	owners, err := h.service.ListOwners(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, owners)
+}
+
+// AssignOwner makes a vendor account owner of a stand
+// @Summary Assign stand owner
+// @Description Give a vendor account the vendor portal of a stand of the festival. The account also needs the VENDOR role.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body AssignOwnerRequest true "Stand owner"
+// @Success 201 {object} response.Response{data=StandOwner} "Stand owner assigned"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Failure 409 {object} response.ErrorResponse "User already owns the stand"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendors [post]
+func (h *Handler) AssignOwner(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req AssignOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	owner, err := h.service.AssignOwner(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, owner)
+}
+
+// RemoveOwner revokes the vendor portal of a stand owner
+// @Summary Remove stand owner
+// @Description Revoke a vendor account's access to a stand, effective on its next request
+// @Tags vendors
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param ownerId path string true "Stand owner ID" format(uuid)
+// @Success 204 "Stand owner removed"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand owner not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendors/{ownerId} [delete]
+func (h *Handler) RemoveOwner(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	ownerID, err := uuid.Parse(c.Param("ownerId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand owner ID", nil)
+		return
+	}
+
+	if err := h.service.RemoveOwner(c.Request.Context(), festivalID, ownerID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListPayouts lists the vendor payouts of the festival
+// @Summary List vendor payouts
+// @Description List the payouts to stand owners, latest period first
+// @Tags vendors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Only the payouts of this stand" format(uuid)
+// @Success 200 {object} response.Response{data=[]Payout} "Payouts"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendor-payouts [get]
+func (h *Handler) ListPayouts(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var standID *uuid.UUID
+	if raw := c.Query("standId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+			return
+		}
+		standID = &id
+	}
+
+	payouts, err := h.service.ListPayouts(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, payouts)
+}
+
+// CreatePayout schedules a payout to the owners of a stand
+// @Summary Create vendor payout
+// @Description Schedule a payout to the owners of a stand, of the statement balance of the period unless an amount is given
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreatePayoutRequest true "Payout"
+// @Success 201 {object} response.Response{data=Payout} "Payout scheduled"
+// @Failure 400 {object} response.ErrorResponse "Invalid payout"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendor-payouts [post]
+func (h *Handler) CreatePayout(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreatePayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	payout, err := h.service.CreatePayout(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, payout)
+}
+
+// UpdatePayout records the outcome of a vendor payout
+// @Summary Update vendor payout
+// @Description Mark a payout PAID or FAILED, or update its bank reference
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param payoutId path string true "Payout ID" format(uuid)
+// @Param request body UpdatePayoutRequest true "Payout changes"
+// @Success 200 {object} response.Response{data=Payout} "Payout updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid payout status"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Payout not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendor-payouts/{payoutId} [patch]
+func (h *Handler) UpdatePayout(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	payoutID, err := uuid.Parse(c.Param("payoutId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid payout ID", nil)
+		return
+	}
+
+	var req UpdatePayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	payout, err := h.service.UpdatePayout(c.Request.Context(), festivalID, payoutID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, payout)
+}
+
+// ListCommissionRules lists the commission rules of the festival
+// @Summary List commission rules
+// @Description List the commission rules of the festival, or those applying to a stand, festival-wide rules included
+// @Tags vendors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]CommissionRule} "Commission rules"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/commission-rules [get]
+func (h *Handler) ListCommissionRules(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var standID *uuid.UUID
+	if raw := c.Query("standId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+			return
+		}
+		standID = &id
+	}
+
+	rules, err := h.service.ListCommissionRules(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, rules)
+}
+
+// CreateCommissionRule overrides the commission of a stand, or of every stand, for a while
+// @Summary Create commission rule
+// @Description Override the commission percent within a time window, for a stand or every stand of the festival, e.g. a 0% fee holiday on opening night. The orders the rule covers from before its creation are corrected with settlement adjustments, returned with the rule.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateCommissionRuleRequest true "Commission rule"
+// @Success 201 {object} response.Response{data=CreateCommissionRuleResponse} "Commission rule created"
+// @Failure 400 {object} response.ErrorResponse "Invalid commission rule"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Failure 409 {object} response.ErrorResponse "Overlapping commission rule"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/commission-rules [post]
+func (h *Handler) CreateCommissionRule(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateCommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	created, err := h.service.CreateCommissionRule(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, created)
+}
+
+// DeleteCommissionRule deletes a commission rule that has not started yet
+// @Summary Delete commission rule
+// @Description Delete a commission rule that has not started yet; started rules are corrected with settlement adjustments
+// @Tags vendors
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param ruleId path string true "Commission rule ID" format(uuid)
+// @Success 204 "Commission rule deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Commission rule not found"
+// @Failure 409 {object} response.ErrorResponse "Commission rule already started"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/commission-rules/{ruleId} [delete]
+func (h *Handler) DeleteCommissionRule(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid commission rule ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteCommissionRule(c.Request.Context(), festivalID, ruleID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListAdjustments lists the settlement adjustments of the festival
+// @Summary List settlement adjustments
+// @Description List the corrections of what the stands of the festival are owed, oldest day first
+// @Tags vendors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param standId query string false "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]SettlementAdjustment} "Settlement adjustments"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/settlement-adjustments [get]
+func (h *Handler) ListAdjustments(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var standID *uuid.UUID
+	if raw := c.Query("standId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+			return
+		}
+		standID = &id
+	}
+
+	adjustments, err := h.service.ListAdjustments(c.Request.Context(), festivalID, standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, adjustments)
+}
+
+// CreateAdjustment corrects what a stand is owed for one of its days
+// @Summary Create settlement adjustment
+// @Description Correct what a stand is owed for an operational day without changing its sales; the adjustment shows on the statements covering the day
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body CreateAdjustmentRequest true "Adjustment"
+// @Success 201 {object} response.Response{data=SettlementAdjustment} "Adjustment created"
+// @Failure 400 {object} response.ErrorResponse "Invalid adjustment"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Stand not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/settlement-adjustments [post]
+func (h *Handler) CreateAdjustment(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	adjustment, err := h.service.CreateAdjustment(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, adjustment)
+}
+
+// ListMenuChanges lists the menu changes submitted by the vendors of the festival
+// @Summary List menu changes
+// @Description The approval queue of the menu changes submitted by vendors, the longest waiting first
+// @Tags vendors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param status query string false "PENDING (default), SCHEDULED, PUBLISHED, REJECTED or FAILED"
+// @Param standId query string false "Only the menu changes of this stand" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]MenuChange,meta=response.Meta} "Menu changes"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/menu-changes [get]
+func (h *Handler) ListMenuChanges(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var standID *uuid.UUID
+	if raw := c.Query("standId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+			return
+		}
+		standID = &id
+	}
+	page, perPage := pagination(c)
+
+	changes, total, err := h.service.ListMenuChanges(c.Request.Context(), festivalID, standID, MenuChangeStatus(c.Query("status")), (page-1)*perPage, perPage)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, changes, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// ApproveMenuChange approves a menu change submitted by a vendor
+// @Summary Approve menu change
+// @Description Approve a submitted menu change. It is published right away, or at the time given or requested by the vendor. The stand owners are notified by email.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param changeId path string true "Menu change ID" format(uuid)
+// @Param request body ApproveMenuChangeRequest false "Approval"
+// @Success 200 {object} response.Response{data=MenuChange} "Menu change published or scheduled"
+// @Failure 400 {object} response.ErrorResponse "Not submitted, or publish time in the past"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Menu change not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/menu-changes/{changeId}/approve [post]
+func (h *Handler) ApproveMenuChange(c *gin.Context) {
+	festivalID, changeID, ok := menuChangeParams(c)
+	if !ok {
+		return
+	}
+
+	var req ApproveMenuChangeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	change, err := h.service.ApproveMenuChange(c.Request.Context(), festivalID, changeID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, change)
+}
+
+// RejectMenuChange rejects a menu change submitted by a vendor
+// @Summary Reject menu change
+// @Description Reject a submitted menu change, or cancel one scheduled for later. The stand owners are notified by email with the note.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param changeId path string true "Menu change ID" format(uuid)
+// @Param request body RejectMenuChangeRequest true "Rejection"
+// @Success 200 {object} response.Response{data=MenuChange} "Menu change rejected"
+// @Failure 400 {object} response.ErrorResponse "Not submitted, or missing note"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Menu change not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/menu-changes/{changeId}/reject [post]
+func (h *Handler) RejectMenuChange(c *gin.Context) {
+	festivalID, changeID, ok := menuChangeParams(c)
+	if !ok {
+		return
+	}
+
+	var req RejectMenuChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	change, err := h.service.RejectMenuChange(c.Request.Context(), festivalID, changeID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, change)
+}
+
+// GetProfitability returns the profitability report of the stands of the festival
+// @Summary Get vendor profitability
+// @Description Net sales, cost of goods, festival commission and card fees of every stand of the festival over a period, highest profit first. Items sold before their product had a cost price count as uncosted sales, without any cost.
+// @Tags vendors
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param from query string false "First day, festival-local (2006-01-02); defaults to the first festival day"
+// @Param to query string false "Last day, festival-local (2006-01-02); defaults to the last festival day"
+// @Success 200 {object} response.Response{data=ProfitabilityReport} "Profitability report"
+// @Failure 400 {object} response.ErrorResponse "Invalid period"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/vendor-profitability [get]
+func (h *Handler) GetProfitability(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	from, ok := dateQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := dateQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetProfitability(c.Request.Context(), festivalID, from, to)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// ListMyStands lists the stands the caller owns
+// @Summary List my vendor stands
+// @Description List the stands the authenticated vendor owns, across festivals
+// @Tags vendor-portal
+// @Produce json
+// @Success 200 {object} response.Response{data=[]OwnedStand} "Owned stands"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Not a vendor"
+// @Security BearerAuth
+// @Router /vendor/stands [get]
+func (h *Handler) ListMyStands(c *gin.Context) {
+	id := userID(c)
+	if id == nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	stands, err := h.service.ListMyStands(c.Request.Context(), *id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, stands)
+}
+
+// GetStand returns an owned stand
+// @Summary Get my vendor stand
+// @Description Get an owned stand with its commission and festival dates
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=OwnedStand} "Stand"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId} [get]
+func (h *Handler) GetStand(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	st, err := h.service.GetStand(c.Request.Context(), standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, st)
+}
+
+// ListProducts lists the products of an owned stand
+// @Summary List my stand products
+// @Description List the products of an owned stand
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Success 200 {object} response.Response{data=[]product.Product,meta=response.Meta} "Products"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/products [get]
+func (h *Handler) ListProducts(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+
+	products, total, err := h.service.ListProducts(c.Request.Context(), standID, page, perPage)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, products, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// CreateProduct drafts a new product of an owned stand
+// @Summary Create my stand product
+// @Description Draft the addition of a product to an owned stand. It reaches the menu once submitted and approved by an organizer.
+// @Tags vendor-portal
+// @Accept json
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param request body CreateProductRequest true "Product"
+// @Success 202 {object} response.Response{data=MenuChange} "Product drafted"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/products [post]
+func (h *Handler) CreateProduct(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	change, err := h.service.CreateProduct(c.Request.Context(), standID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, change)
+}
+
+// UpdateProduct updates a product of an owned stand
+// @Summary Update my stand product
+// @Description Update the stock or status of a product of an owned stand right away. Other changes, e.g. to its price, are drafted until approved by an organizer.
+// @Tags vendor-portal
+// @Accept json
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param productId path string true "Product ID" format(uuid)
+// @Param request body product.UpdateProductRequest true "Product changes"
+// @Success 200 {object} response.Response{data=product.Product} "Stock or status updated"
+// @Success 202 {object} response.Response{data=MenuChange} "Menu changes drafted"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/products/{productId} [patch]
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	standID, productID, ok := productParams(c)
+	if !ok {
+		return
+	}
+
+	var req product.UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	p, change, err := h.service.UpdateProduct(c.Request.Context(), standID, productID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if change != nil {
+		response.Accepted(c, change)
+		return
+	}
+	response.OK(c, p)
+}
+
+// DeleteProduct drafts the removal of a product of an owned stand
+// @Summary Delete my stand product
+// @Description Draft the removal of a product of an owned stand. It leaves the menu once submitted and approved by an organizer.
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param productId path string true "Product ID" format(uuid)
+// @Success 202 {object} response.Response{data=MenuChange} "Removal drafted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Failure 404 {object} response.ErrorResponse "Product not found"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/products/{productId} [delete]
+func (h *Handler) DeleteProduct(c *gin.Context) {
+	standID, productID, ok := productParams(c)
+	if !ok {
+		return
+	}
+
+	change, err := h.service.DeleteProduct(c.Request.Context(), standID, productID, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, change)
+}
+
+// ListStandMenuChanges lists the menu changes of an owned stand
+// @Summary List my stand menu changes
+// @Description List the drafted, submitted and reviewed menu changes of an owned stand, the longest waiting first
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param status query string false "DRAFT, PENDING, SCHEDULED, PUBLISHED, REJECTED or FAILED"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]MenuChange,meta=response.Meta} "Menu changes"
+// @Failure 400 {object} response.ErrorResponse "Invalid status"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/menu-changes [get]
+func (h *Handler) ListStandMenuChanges(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+	page, perPage := pagination(c)
+
+	changes, total, err := h.service.ListStandMenuChanges(c.Request.Context(), standID, MenuChangeStatus(c.Query("status")), (page-1)*perPage, perPage)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, changes, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// SubmitMenuChange submits a draft menu change of an owned stand for approval
+// @Summary Submit my stand menu change
+// @Description Submit a draft menu change to the organizers, optionally to be published no earlier than a given time
+// @Tags vendor-portal
+// @Accept json
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param changeId path string true "Menu change ID" format(uuid)
+// @Param request body SubmitMenuChangeRequest false "Publication time"
+// @Success 200 {object} response.Response{data=MenuChange} "Menu change submitted"
+// @Failure 400 {object} response.ErrorResponse "Not a draft, or publish time in the past"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Failure 404 {object} response.ErrorResponse "Menu change not found"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/menu-changes/{changeId}/submit [post]
+func (h *Handler) SubmitMenuChange(c *gin.Context) {
+	standID, changeID, ok := standMenuChangeParams(c)
+	if !ok {
+		return
+	}
+
+	var req SubmitMenuChangeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	change, err := h.service.SubmitMenuChange(c.Request.Context(), standID, changeID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, change)
+}
+
+// DiscardMenuChange drops a menu change of an owned stand
+// @Summary Discard my stand menu change
+// @Description Drop a draft menu change, or withdraw one submitted but not reviewed yet
+// @Tags vendor-portal
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param changeId path string true "Menu change ID" format(uuid)
+// @Success 204 "Menu change discarded"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Failure 404 {object} response.ErrorResponse "Menu change not found"
+// @Failure 409 {object} response.ErrorResponse "Menu change already reviewed"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/menu-changes/{changeId} [delete]
+func (h *Handler) DiscardMenuChange(c *gin.Context) {
+	standID, changeID, ok := standMenuChangeParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DiscardMenuChange(c.Request.Context(), standID, changeID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// GetLiveSales returns today's sales of an owned stand
+// @Summary Get my stand live sales
+// @Description Sales of an owned stand since the start of the festival-local day, with hourly figures, top products and the latest orders
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=LiveSales} "Live sales"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/sales/live [get]
+func (h *Handler) GetLiveSales(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	live, err := h.service.GetLiveSales(c.Request.Context(), standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, live)
+}
+
+// GetStatement returns the settlement statement of an owned stand
+// @Summary Get my stand statement
+// @Description Daily sales, refunds, festival commission and amount payable of an owned stand, against the payouts made
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param from query string false "First day, festival-local (2006-01-02); defaults to the first festival day"
+// @Param to query string false "Last day, festival-local (2006-01-02); defaults to the last festival day"
+// @Success 200 {object} response.Response{data=Statement} "Statement"
+// @Failure 400 {object} response.ErrorResponse "Invalid period"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/statement [get]
+func (h *Handler) GetStatement(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	from, ok := dateQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := dateQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	statement, err := h.service.GetStatement(c.Request.Context(), standID, from, to)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, statement)
+}
+
+// GetPayouts returns the payout status of an owned stand
+// @Summary Get my stand payouts
+// @Description What an owned stand earned to date against what was paid out or scheduled, with its payouts
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=PayoutSummary} "Payouts"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/payouts [get]
+func (h *Handler) GetPayouts(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetPayouts(c.Request.Context(), standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, summary)
+}
+
+// ListStaff lists the staff of an owned stand
+// @Summary List my stand staff
+// @Description List the staff members assigned to an owned stand
+// @Tags vendor-portal
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]stand.StandStaffResponse} "Staff"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/staff [get]
+func (h *Handler) ListStaff(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	staff, err := h.service.ListStaff(c.Request.Context(), standID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]stand.StandStaffResponse, len(staff))
+	for i, s := range staff {
+		items[i] = s.ToResponse()
+	}
+
+	response.OK(c, items)
+}
+
+// AssignStaff assigns a staff member to an owned stand
+// @Summary Assign my stand staff
+// @Description Assign a user to an owned stand with a role and optional PIN
+// @Tags vendor-portal
+// @Accept json
+// @Produce json
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param request body stand.AssignStaffRequest true "Staff assignment"
+// @Success 201 {object} response.Response{data=stand.StandStaffResponse} "Staff assigned"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Failure 409 {object} response.ErrorResponse "User already assigned"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/staff [post]
+func (h *Handler) AssignStaff(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+
+	var req stand.AssignStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	member, err := h.service.AssignStaff(c.Request.Context(), standID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, member.ToResponse())
+}
+
+// RemoveStaff removes a staff member from an owned stand
+// @Summary Remove my stand staff
+// @Description Remove a staff member's assignment from an owned stand
+// @Tags vendor-portal
+// @Param standId path string true "Stand ID" format(uuid)
+// @Param userId path string true "User ID" format(uuid)
+// @Success 204 "Staff removed"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Stand not owned"
+// @Failure 404 {object} response.ErrorResponse "Staff member not found"
+// @Security BearerAuth
+// @Router /vendor/stands/{standId}/staff/{userId} [delete]
+func (h *Handler) RemoveStaff(c *gin.Context) {
+	standID, ok := standParam(c)
+	if !ok {
+		return
+	}
+	staffUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid user ID", nil)
+		return
+	}
+
+	if err := h.service.RemoveStaff(c.Request.Context(), standID, staffUserID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func standParam(c *gin.Context) (uuid.UUID, bool) {
+	standID, err := uuid.Parse(c.Param("standId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid stand ID", nil)
+		return uuid.Nil, false
+	}
+	return standID, true
+}
+
+func productParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	standID, ok := standParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid product ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return standID, productID, true
+}
+
+func standMenuChangeParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	standID, ok := standParam(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	changeID, err := uuid.Parse(c.Param("changeId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid menu change ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return standID, changeID, true
+}
+
+func menuChangeParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	changeID, err := uuid.Parse(c.Param("changeId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid menu change ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, changeID, true
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+// dateQuery parses an optional 2006-01-02 query parameter
+func dateQuery(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid "+name+" date, expected YYYY-MM-DD", nil)
+		return nil, false
+	}
+	return &date, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrStandNotFound):
+		response.NotFound(c, "Stand not found")
+	case errors.Is(err, ErrOwnerNotFound):
+		response.NotFound(c, "Stand owner not found")
+	case errors.Is(err, ErrProductNotFound):
+		response.NotFound(c, "Product not found")
+	case errors.Is(err, ErrStaffNotFound):
+		response.NotFound(c, "Staff member not found")
+	case errors.Is(err, ErrPayoutNotFound):
+		response.NotFound(c, "Payout not found")
+	case errors.Is(err, ErrMenuChangeNotFound):
+		response.NotFound(c, "Menu change not found")
+	case errors.Is(err, ErrCommissionRuleNotFound):
+		response.NotFound(c, "Commission rule not found")
+	case errors.Is(err, ErrAlreadyOwner):
+		response.Conflict(c, "ALREADY_OWNER", err.Error())
+	case errors.Is(err, ErrAlreadyStaff):
+		response.Conflict(c, "ALREADY_ASSIGNED", err.Error())
+	case errors.Is(err, ErrInvalidStaffRole):
+		response.BadRequest(c, "INVALID_ROLE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidPeriod), errors.Is(err, ErrPeriodTooLong):
+		response.BadRequest(c, "INVALID_PERIOD", err.Error(), nil)
+	case errors.Is(err, ErrInvalidPayoutStatus), errors.Is(err, ErrInvalidPayoutAmount):
+		response.BadRequest(c, "INVALID_PAYOUT", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRuleWindow):
+		response.BadRequest(c, "INVALID_WINDOW", err.Error(), nil)
+	case errors.Is(err, ErrCommissionRuleOverlap):
+		response.Conflict(c, "RULE_OVERLAP", err.Error())
+	case errors.Is(err, ErrCommissionRuleStarted):
+		response.Conflict(c, "RULE_STARTED", err.Error())
+	case errors.Is(err, ErrInvalidAdjustmentDate):
+		response.BadRequest(c, "INVALID_DATE", err.Error(), nil)
+	case errors.Is(err, ErrMenuChangeClosed):
+		response.Conflict(c, "ALREADY_REVIEWED", err.Error())
+	case errors.Is(err, ErrMenuChangeNotDraft), errors.Is(err, ErrMenuChangeNotPending):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrInvalidMenuChangeStatus):
+		response.BadRequest(c, "INVALID_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrPublishAtInPast):
+		response.BadRequest(c, "INVALID_PUBLISH_AT", err.Error(), nil)
+	case errors.Is(err, ErrSchedulingUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,519 @@
+package vendorportal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+)
+
+// Vendor portal errors
+var (
+	ErrStandNotFound       = errors.New("stand not found")
+	ErrOwnerNotFound       = errors.New("stand owner not found")
+	ErrAlreadyOwner        = errors.New("user already owns this stand")
+	ErrProductNotFound     = errors.New("product not found")
+	ErrStaffNotFound       = errors.New("staff member not found")
+	ErrAlreadyStaff        = errors.New("user already assigned to this stand")
+	ErrInvalidStaffRole    = errors.New("staff role must be MANAGER, CASHIER or ASSISTANT")
+	ErrInvalidPeriod       = errors.New("period must end after it starts")
+	ErrPeriodTooLong       = errors.New("statements cover at most 92 days")
+	ErrPayoutNotFound      = errors.New("payout not found")
+	ErrInvalidPayoutStatus = errors.New("payout status must be PENDING, PAID or FAILED")
+	ErrInvalidPayoutAmount = errors.New("payout amount must be positive")
+
+	ErrCommissionRuleNotFound = errors.New("commission rule not found")
+	ErrInvalidRuleWindow      = errors.New("commission rule must end after it starts")
+	ErrCommissionRuleOverlap  = errors.New("commission rule overlaps another rule of the same stand")
+	ErrCommissionRuleStarted  = errors.New("commission rule already started; add an adjustment instead")
+	ErrInvalidAdjustmentDate  = errors.New("adjustment date must be formatted as YYYY-MM-DD")
+
+	ErrMenuChangeNotFound      = errors.New("menu change not found")
+	ErrMenuChangeNotDraft      = errors.New("only draft menu changes can be submitted")
+	ErrMenuChangeNotPending    = errors.New("only submitted menu changes can be reviewed")
+	ErrMenuChangeClosed        = errors.New("menu change was already reviewed")
+	ErrInvalidMenuChangeStatus = errors.New("status must be PENDING, SCHEDULED, PUBLISHED, REJECTED or FAILED")
+	ErrPublishAtInPast         = errors.New("publish time must be in the future")
+	ErrSchedulingUnavailable   = errors.New("scheduled publishing is not available")
+)
+
+// Live sales and statement limits
+const (
+	MaxStatementDays  = 92
+	TopProductsLimit  = 10
+	RecentOrdersLimit = 20
+)
+
+// StandOwner grants a vendor account the vendor portal for one stand
+type StandOwner struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    uuid.UUID  `json:"standId" gorm:"type:uuid;not null"`
+	UserID     uuid.UUID  `json:"userId" gorm:"type:uuid;not null;index"`
+	CreatedBy  *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (StandOwner) TableName() string {
+	return "stand_owners"
+}
+
+// OwnedStand is a stand as seen by its owners, with what the portal needs of its festival
+type OwnedStand struct {
+	ID                uuid.UUID `json:"id"`
+	FestivalID        uuid.UUID `json:"festivalId"`
+	FestivalName      string    `json:"festivalName"`
+	Name              string    `json:"name"`
+	Category          string    `json:"category"`
+	Location          string    `json:"location,omitempty"`
+	Status            string    `json:"status"`
+	CommissionPercent float64   `json:"commissionPercent"`
+	CardFeePercent    float64   `json:"cardFeePercent"`
+	Timezone          string    `json:"timezone"`
+	DayStartsAt       string    `json:"dayStartsAt"` // Local time the festival's operational day starts at, HH:MM
+	StartDate         time.Time `json:"festivalStartDate"`
+	EndDate           time.Time `json:"festivalEndDate"`
+}
+
+// Calendar returns the operational days of the festival of the stand
+func (s *OwnedStand) Calendar() tz.Calendar {
+	return tz.NewCalendar(s.Timezone, s.DayStartsAt)
+}
+
+// PayoutStatus is where a payout to the owners of a stand stands
+type PayoutStatus string
+
+const (
+	PayoutStatusPending PayoutStatus = "PENDING" // Scheduled, not sent yet
+	PayoutStatusPaid    PayoutStatus = "PAID"
+	PayoutStatusFailed  PayoutStatus = "FAILED" // Rejected by the bank, to be scheduled again
+)
+
+// IsValid checks if the payout status is valid
+func (s PayoutStatus) IsValid() bool {
+	return s == PayoutStatusPending || s == PayoutStatusPaid || s == PayoutStatusFailed
+}
+
+// Payout is the payment of a statement balance to the owners of a stand
+type Payout struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID     uuid.UUID    `json:"standId" gorm:"type:uuid;not null;index"`
+	PeriodStart time.Time    `json:"periodStart" gorm:"not null"`
+	PeriodEnd   time.Time    `json:"periodEnd" gorm:"not null"`
+	Amount      int64        `json:"amount" gorm:"not null"` // In cents
+	Status      PayoutStatus `json:"status" gorm:"not null;default:'PENDING'"`
+	Reference   string       `json:"reference,omitempty"` // Bank transfer reference
+	Notes       string       `json:"notes,omitempty"`
+	PaidAt      *time.Time   `json:"paidAt,omitempty"`
+	CreatedBy   *uuid.UUID   `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+func (Payout) TableName() string {
+	return "vendor_payouts"
+}
+
+// CommissionRule replaces the commission percent of the orders taken within its window,
+// for one stand or for every stand of the festival (StandID nil), e.g. a 0% fee holiday
+// on opening night. A stand rule takes precedence over a festival-wide one. A rule only
+// applies to the orders taken after it was created: the orders it covers from before
+// are corrected with settlement adjustments, so that statements already sent keep
+// their days.
+type CommissionRule struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    *uuid.UUID `json:"standId,omitempty" gorm:"type:uuid"`
+	Label      string     `json:"label" gorm:"not null"`
+	Percent    float64    `json:"percent" gorm:"not null"`
+	StartsAt   time.Time  `json:"startsAt" gorm:"not null"`
+	EndsAt     time.Time  `json:"endsAt" gorm:"not null"` // Exclusive
+	CreatedBy  *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (CommissionRule) TableName() string {
+	return "commission_rules"
+}
+
+// Overlaps reports whether two rules apply to the same stands at the same time
+func (r *CommissionRule) Overlaps(other *CommissionRule) bool {
+	sameScope := (r.StandID == nil && other.StandID == nil) ||
+		(r.StandID != nil && other.StandID != nil && *r.StandID == *other.StandID)
+	return sameScope && r.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(r.EndsAt)
+}
+
+// SettlementAdjustment corrects what a stand is owed for one operational day without
+// changing the sales of the day, e.g. a commission rule created after the fact or a
+// goodwill gesture. Adjustments are never edited; a wrong one is reversed by another.
+type SettlementAdjustment struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID    uuid.UUID  `json:"standId" gorm:"type:uuid;not null;index"`
+	Date       time.Time  `json:"date" gorm:"type:date;not null"` // Operational day corrected, as a UTC midnight
+	Amount     int64      `json:"amount" gorm:"not null"`         // Owed to the vendor, negative when owed by it
+	Reason     string     `json:"reason" gorm:"not null"`
+	RuleID     *uuid.UUID `json:"ruleId,omitempty" gorm:"type:uuid"` // Commission rule applied after the fact
+	CreatedBy  *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (SettlementAdjustment) TableName() string {
+	return "settlement_adjustments"
+}
+
+// MenuChangeAction is what a menu change does to the products of a stand
+type MenuChangeAction string
+
+const (
+	MenuChangeCreate MenuChangeAction = "CREATE"
+	MenuChangeUpdate MenuChangeAction = "UPDATE"
+	MenuChangeDelete MenuChangeAction = "DELETE"
+)
+
+// MenuChangeStatus is where a menu change stands in the approval workflow
+type MenuChangeStatus string
+
+const (
+	MenuChangeDraft     MenuChangeStatus = "DRAFT"     // Being edited by the vendor
+	MenuChangePending   MenuChangeStatus = "PENDING"   // Submitted, waiting for an organizer
+	MenuChangeScheduled MenuChangeStatus = "SCHEDULED" // Approved, published at PublishAt
+	MenuChangePublished MenuChangeStatus = "PUBLISHED"
+	MenuChangeRejected  MenuChangeStatus = "REJECTED"
+	MenuChangeFailed    MenuChangeStatus = "FAILED" // Approved, but the product changed in between
+)
+
+// IsValid checks if the menu change status is valid
+func (s MenuChangeStatus) IsValid() bool {
+	switch s {
+	case MenuChangeDraft, MenuChangePending, MenuChangeScheduled, MenuChangePublished, MenuChangeRejected, MenuChangeFailed:
+		return true
+	}
+	return false
+}
+
+// MenuChange is a change of the menu of a stand made by its vendor. It reaches the
+// published menu only once approved by an organizer, immediately or at PublishAt.
+type MenuChange struct {
+	ID           uuid.UUID                     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID   uuid.UUID                     `json:"festivalId" gorm:"type:uuid;not null;index"`
+	StandID      uuid.UUID                     `json:"standId" gorm:"type:uuid;not null;index"`
+	ProductID    *uuid.UUID                    `json:"productId,omitempty" gorm:"type:uuid"` // Set on publication for creations
+	Action       MenuChangeAction              `json:"action" gorm:"not null"`
+	ProductName  string                        `json:"productName"`
+	CurrentPrice *int64                        `json:"currentPrice,omitempty"` // Published price when the change was drafted, in cents
+	Create       *CreateProductRequest         `json:"create,omitempty" gorm:"column:new_product;type:jsonb;serializer:json"`
+	Update       *product.UpdateProductRequest `json:"update,omitempty" gorm:"column:product_changes;type:jsonb;serializer:json"`
+	Status       MenuChangeStatus              `json:"status" gorm:"not null;default:'DRAFT'"`
+	PublishAt    *time.Time                    `json:"publishAt,omitempty"` // Requested by the vendor, or set by the organizer
+	ReviewNote   string                        `json:"reviewNote,omitempty"`
+	Error        string                        `json:"error,omitempty"`
+	CreatedBy    *uuid.UUID                    `json:"createdBy,omitempty" gorm:"type:uuid"`
+	SubmittedBy  *uuid.UUID                    `json:"submittedBy,omitempty" gorm:"type:uuid"`
+	SubmittedAt  *time.Time                    `json:"submittedAt,omitempty"`
+	ReviewedBy   *uuid.UUID                    `json:"reviewedBy,omitempty" gorm:"type:uuid"`
+	ReviewedAt   *time.Time                    `json:"reviewedAt,omitempty"`
+	PublishedAt  *time.Time                    `json:"publishedAt,omitempty"`
+	CreatedAt    time.Time                     `json:"createdAt"`
+	UpdatedAt    time.Time                     `json:"updatedAt"`
+}
+
+func (MenuChange) TableName() string {
+	return "menu_changes"
+}
+
+// MenuChangeFilter filters the listed menu changes
+type MenuChangeFilter struct {
+	FestivalID *uuid.UUID
+	StandID    *uuid.UUID
+	Status     MenuChangeStatus
+	Offset     int
+	Limit      int
+}
+
+// OwnerContact is how to reach an owner of a stand
+type OwnerContact struct {
+	Email  string
+	Locale string
+}
+
+// MenuChangeNotice tells a stand owner that an organizer reviewed a menu change
+type MenuChangeNotice struct {
+	To           string
+	Locale       string
+	FestivalID   uuid.UUID
+	FestivalName string
+	StandName    string
+	ProductName  string
+	Action       MenuChangeAction
+	Approved     bool
+	PublishAt    *time.Time // Approved changes published later
+	Note         string
+}
+
+// DailySales sums the orders of a stand taken on one festival-local day
+type DailySales struct {
+	Date       string `json:"date"` // 2006-01-02
+	Orders     int64  `json:"orders"`
+	GrossSales int64  `json:"grossSales"` // Paid and later refunded orders
+	Refunds    int64  `json:"refunds"`    // Refunded orders
+
+	Rules []RuleSales `json:"-" gorm:"-"` // Part of the sales under commission rules
+}
+
+// RuleSales sums the orders of a stand taken on one festival-local day under one
+// commission rule
+type RuleSales struct {
+	StandID    uuid.UUID
+	Date       string
+	RuleID     uuid.UUID
+	Label      string
+	Percent    float64
+	StandRule  bool // The rule is specific to the stand
+	Orders     int64
+	GrossSales int64
+	Refunds    int64
+}
+
+// HourlySales sums the paid orders of a stand taken in one hour
+type HourlySales struct {
+	Hour    time.Time `json:"hour"`
+	Orders  int64     `json:"orders"`
+	Revenue int64     `json:"revenue"`
+}
+
+// ProductSales sums the paid order items of one product
+type ProductSales struct {
+	ProductID uuid.UUID `json:"productId"`
+	Name      string    `json:"name"`
+	Quantity  int64     `json:"quantity"`
+	Revenue   int64     `json:"revenue"`
+}
+
+// RecentOrder is an order of the live sales feed
+type RecentOrder struct {
+	ID            uuid.UUID `json:"id"`
+	TotalAmount   int64     `json:"totalAmount"`
+	Status        string    `json:"status"`
+	PaymentMethod string    `json:"paymentMethod"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// LiveSales is the sales of a stand since the start of the festival-local day
+type LiveSales struct {
+	StandID         uuid.UUID      `json:"standId"`
+	Date            string         `json:"date"`
+	Since           time.Time      `json:"since"`
+	Orders          int64          `json:"orders"`
+	Revenue         int64          `json:"revenue"` // Paid orders, refunds excluded
+	AverageOrder    int64          `json:"averageOrder"`
+	Refunds         int64          `json:"refunds"`
+	LastHourOrders  int64          `json:"lastHourOrders"` // Current and previous clock hour
+	LastHourRevenue int64          `json:"lastHourRevenue"`
+	Hourly          []HourlySales  `json:"hourly"`
+	TopProducts     []ProductSales `json:"topProducts"`
+	RecentOrders    []RecentOrder  `json:"recentOrders"`
+	GeneratedAt     time.Time      `json:"generatedAt"`
+}
+
+// StatementDay is one day of a statement
+type StatementDay struct {
+	Date       string `json:"date"`
+	Orders     int64  `json:"orders"`
+	GrossSales int64  `json:"grossSales"`
+	Refunds    int64  `json:"refunds"`
+	NetSales   int64  `json:"netSales"`   // Gross sales minus refunds
+	Commission int64  `json:"commission"` // Festival's cut of the net sales
+	Payable    int64  `json:"payable"`    // Net sales minus commission, owed to the vendor
+
+	Rules []StatementRule `json:"rules,omitempty"` // Sales under commission rules
+}
+
+// StatementRule is the part of a statement day under a commission rule
+type StatementRule struct {
+	RuleID     uuid.UUID `json:"ruleId"`
+	Label      string    `json:"label"`
+	Percent    float64   `json:"percent"`
+	Orders     int64     `json:"orders"`
+	NetSales   int64     `json:"netSales"`
+	Commission int64     `json:"commission"`
+}
+
+// StatementTotals sums the days of a statement
+type StatementTotals struct {
+	Orders     int64 `json:"orders"`
+	GrossSales int64 `json:"grossSales"`
+	Refunds    int64 `json:"refunds"`
+	NetSales   int64 `json:"netSales"`
+	Commission int64 `json:"commission"`
+	Payable    int64 `json:"payable"`
+
+	Adjustments int64 `json:"adjustments"` // Corrections of the days of the statement
+}
+
+// Statement is the settlement statement of a stand over a period
+type Statement struct {
+	StandID           uuid.UUID              `json:"standId"`
+	StandName         string                 `json:"standName"`
+	PeriodStart       time.Time              `json:"periodStart"`
+	PeriodEnd         time.Time              `json:"periodEnd"`
+	CommissionPercent float64                `json:"commissionPercent"` // Outside commission rules
+	Days              []StatementDay         `json:"days"`
+	Adjustments       []SettlementAdjustment `json:"adjustments"` // Corrections of the days, oldest first
+	Totals            StatementTotals        `json:"totals"`
+	Paid              int64                  `json:"paid"`    // Paid payouts starting in the period
+	Balance           int64                  `json:"balance"` // Payable plus adjustments minus paid
+	GeneratedAt       time.Time              `json:"generatedAt"`
+}
+
+// PayoutSummary is where the payouts of a stand stand against what it earned
+type PayoutSummary struct {
+	StandID     uuid.UUID `json:"standId"`
+	Earned      int64     `json:"earned"` // Payable to date, adjustments included
+	Paid        int64     `json:"paid"`
+	Pending     int64     `json:"pending"`
+	Outstanding int64     `json:"outstanding"` // Earned but neither paid nor scheduled
+	Payouts     []Payout  `json:"payouts"`
+}
+
+// StandDailyCosts sums the orders of a stand taken on one festival-local day, with the
+// card sales and the cost of goods the profitability report needs
+type StandDailyCosts struct {
+	StandID uuid.UUID
+	DailySales
+	CardSales     int64 // Paid card orders
+	CostOfGoods   int64 // Items of the paid orders with a cost price
+	UncostedSales int64 // Items of the paid orders without a cost price
+}
+
+// VendorProfitability contrasts the sales of a stand with what they cost its vendor
+type VendorProfitability struct {
+	StandID           uuid.UUID `json:"standId,omitempty"`
+	StandName         string    `json:"standName,omitempty"`
+	CommissionPercent float64   `json:"commissionPercent,omitempty"`
+	CardFeePercent    float64   `json:"cardFeePercent,omitempty"`
+	Orders            int64     `json:"orders"`
+	NetSales          int64     `json:"netSales"`      // Gross sales minus refunds
+	UncostedSales     int64     `json:"uncostedSales"` // Sold before the product had a cost price
+	CostOfGoods       int64     `json:"costOfGoods"`
+	Commission        int64     `json:"commission"` // As on the statement
+	Fees              int64     `json:"fees"`       // Card processing fees on the card sales
+	Profit            int64     `json:"profit"`     // Net sales minus cost of goods, commission and fees
+	MarginPercent     float64   `json:"marginPercent"`
+}
+
+// ProfitabilityReport is the profitability of the stands of a festival over a period
+type ProfitabilityReport struct {
+	FestivalID  uuid.UUID             `json:"festivalId"`
+	PeriodStart time.Time             `json:"periodStart"`
+	PeriodEnd   time.Time             `json:"periodEnd"`
+	Vendors     []VendorProfitability `json:"vendors"` // Highest profit first
+	Totals      VendorProfitability   `json:"totals"`
+	GeneratedAt time.Time             `json:"generatedAt"`
+}
+
+// AssignOwnerRequest represents the request to make a vendor account owner of a stand
+type AssignOwnerRequest struct {
+	StandID uuid.UUID `json:"standId" binding:"required"`
+	UserID  uuid.UUID `json:"userId" binding:"required"`
+}
+
+// CreateProductRequest represents the request to add a product to an owned stand
+type CreateProductRequest struct {
+	Name                    string                  `json:"name" binding:"required"`
+	Description             string                  `json:"description"`
+	NameTranslations        i18n.Text               `json:"nameTranslations"`
+	DescriptionTranslations i18n.Text               `json:"descriptionTranslations"`
+	Price                   int64                   `json:"price" binding:"required,min=0"`
+	CostPrice               *int64                  `json:"costPrice" binding:"omitempty,min=0"`
+	Category                product.ProductCategory `json:"category" binding:"required"`
+	CategoryID              *uuid.UUID              `json:"categoryId"`
+	TaxClass                string                  `json:"taxClass"`
+	ImageURL                string                  `json:"imageUrl"`
+	SKU                     string                  `json:"sku"`
+	Stock                   *int                    `json:"stock"`
+	SortOrder               int                     `json:"sortOrder"`
+	Tags                    []string                `json:"tags"`
+}
+
+// ToProductRequest returns the product request for the stand
+func (r CreateProductRequest) ToProductRequest(standID uuid.UUID) product.CreateProductRequest {
+	return product.CreateProductRequest{
+		StandID:                 standID,
+		Name:                    r.Name,
+		Description:             r.Description,
+		NameTranslations:        r.NameTranslations,
+		DescriptionTranslations: r.DescriptionTranslations,
+		Price:                   r.Price,
+		CostPrice:               r.CostPrice,
+		Category:                r.Category,
+		CategoryID:              r.CategoryID,
+		TaxClass:                r.TaxClass,
+		ImageURL:                r.ImageURL,
+		SKU:                     r.SKU,
+		Stock:                   r.Stock,
+		SortOrder:               r.SortOrder,
+		Tags:                    r.Tags,
+	}
+}
+
+// SubmitMenuChangeRequest represents the request to submit a draft menu change for approval
+type SubmitMenuChangeRequest struct {
+	PublishAt *time.Time `json:"publishAt,omitempty"` // Publish no earlier than this time once approved
+}
+
+// ApproveMenuChangeRequest represents the request to approve a submitted menu change
+type ApproveMenuChangeRequest struct {
+	PublishAt *time.Time `json:"publishAt,omitempty"` // Overrides the time requested by the vendor
+	Note      string     `json:"note" binding:"max=500"`
+}
+
+// RejectMenuChangeRequest represents the request to reject a submitted menu change
+type RejectMenuChangeRequest struct {
+	Note string `json:"note" binding:"required,max=500"`
+}
+
+// CreatePayoutRequest represents the request to schedule a payout to the owners of a stand
+type CreatePayoutRequest struct {
+	StandID     uuid.UUID `json:"standId" binding:"required"`
+	PeriodStart time.Time `json:"periodStart" binding:"required"`
+	PeriodEnd   time.Time `json:"periodEnd" binding:"required"`
+	Amount      *int64    `json:"amount,omitempty"` // Defaults to the statement balance of the period
+	Reference   string    `json:"reference" binding:"max=100"`
+	Notes       string    `json:"notes" binding:"max=500"`
+}
+
+// UpdatePayoutRequest represents the request to update the status of a payout
+type UpdatePayoutRequest struct {
+	Status    *PayoutStatus `json:"status,omitempty"`
+	Reference *string       `json:"reference,omitempty" binding:"omitempty,max=100"`
+	Notes     *string       `json:"notes,omitempty" binding:"omitempty,max=500"`
+}
+
+// CreateCommissionRuleRequest represents the request to create a commission rule
+type CreateCommissionRuleRequest struct {
+	StandID  *uuid.UUID `json:"standId,omitempty"` // Every stand of the festival when empty
+	Label    string     `json:"label" binding:"required,max=100"`
+	Percent  *float64   `json:"percent" binding:"required,min=0,max=100"` // 0 for a fee holiday
+	StartsAt time.Time  `json:"startsAt" binding:"required"`
+	EndsAt   time.Time  `json:"endsAt" binding:"required"`
+}
+
+// CreateCommissionRuleResponse is a created commission rule with the adjustments
+// correcting the orders it covers from before its creation
+type CreateCommissionRuleResponse struct {
+	Rule        CommissionRule         `json:"rule"`
+	Adjustments []SettlementAdjustment `json:"adjustments"`
+}
+
+// CreateAdjustmentRequest represents the request to correct what a stand is owed for a day
+type CreateAdjustmentRequest struct {
+	StandID uuid.UUID `json:"standId" binding:"required"`
+	Date    string    `json:"date" binding:"required"`   // Operational day corrected, YYYY-MM-DD
+	Amount  int64     `json:"amount" binding:"required"` // Owed to the vendor, negative when owed by it
+	Reason  string    `json:"reason" binding:"required,max=500"`
+}
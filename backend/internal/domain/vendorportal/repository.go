@@ -0,0 +1,533 @@
+package vendorportal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateOwner(ctx context.Context, owner *StandOwner) error
+	GetOwner(ctx context.Context, festivalID, id uuid.UUID) (*StandOwner, error)
+	GetOwnerByStand(ctx context.Context, standID, userID uuid.UUID) (*StandOwner, error)
+	ListOwners(ctx context.Context, festivalID uuid.UUID) ([]StandOwner, error)
+	DeleteOwner(ctx context.Context, id uuid.UUID) error
+
+	GetStand(ctx context.Context, standID uuid.UUID) (*OwnedStand, error)
+	ListOwnedStands(ctx context.Context, userID uuid.UUID) ([]OwnedStand, error)
+	ListFestivalStands(ctx context.Context, festivalID uuid.UUID) ([]OwnedStand, error)
+
+	GetDailySales(ctx context.Context, standID uuid.UUID, from, to time.Time, cal tz.Calendar) ([]DailySales, error)
+	GetHourlySales(ctx context.Context, standID uuid.UUID, from, to time.Time) ([]HourlySales, error)
+	GetTopProducts(ctx context.Context, standID uuid.UUID, from, to time.Time, limit int) ([]ProductSales, error)
+	GetRecentOrders(ctx context.Context, standID uuid.UUID, limit int) ([]RecentOrder, error)
+	GetDailyCosts(ctx context.Context, festivalID uuid.UUID, from, to time.Time, cal tz.Calendar) ([]StandDailyCosts, error)
+
+	CreatePayout(ctx context.Context, payout *Payout) error
+	GetPayout(ctx context.Context, festivalID, id uuid.UUID) (*Payout, error)
+	UpdatePayout(ctx context.Context, payout *Payout) error
+	ListPayouts(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Payout, error)
+
+	CreateCommissionRule(ctx context.Context, rule *CommissionRule, adjustments []SettlementAdjustment) error
+	GetCommissionRule(ctx context.Context, festivalID, id uuid.UUID) (*CommissionRule, error)
+	ListCommissionRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]CommissionRule, error)
+	DeleteCommissionRule(ctx context.Context, id uuid.UUID) error
+	GetRuleSales(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time, cal tz.Calendar) ([]RuleSales, error)
+	CreateAdjustment(ctx context.Context, adjustment *SettlementAdjustment) error
+	ListAdjustments(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]SettlementAdjustment, error)
+
+	CreateMenuChange(ctx context.Context, change *MenuChange) error
+	GetMenuChange(ctx context.Context, id uuid.UUID) (*MenuChange, error)
+	GetProductDraft(ctx context.Context, standID, productID uuid.UUID) (*MenuChange, error)
+	UpdateMenuChange(ctx context.Context, change *MenuChange) error
+	DeleteMenuChange(ctx context.Context, id uuid.UUID) error
+	ListMenuChanges(ctx context.Context, filter MenuChangeFilter) ([]MenuChange, int64, error)
+	ListOwnerContacts(ctx context.Context, standID uuid.UUID) ([]OwnerContact, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateOwner(ctx context.Context, owner *StandOwner) error {
+	if err := r.db.WithContext(ctx).Create(owner).Error; err != nil {
+		return fmt.Errorf("failed to create stand owner: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetOwner(ctx context.Context, festivalID, id uuid.UUID) (*StandOwner, error) {
+	var owner StandOwner
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&owner).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get stand owner: %w", err)
+	}
+	return &owner, nil
+}
+
+func (r *repository) GetOwnerByStand(ctx context.Context, standID, userID uuid.UUID) (*StandOwner, error) {
+	var owner StandOwner
+	err := r.db.WithContext(ctx).Where("stand_id = ? AND user_id = ?", standID, userID).First(&owner).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get stand owner: %w", err)
+	}
+	return &owner, nil
+}
+
+func (r *repository) ListOwners(ctx context.Context, festivalID uuid.UUID) ([]StandOwner, error) {
+	var owners []StandOwner
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ?", festivalID).
+		Order("stand_id, created_at").
+		Find(&owners).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stand owners: %w", err)
+	}
+	return owners, nil
+}
+
+func (r *repository) DeleteOwner(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&StandOwner{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete stand owner: %w", err)
+	}
+	return nil
+}
+
+const ownedStandColumns = `
+	s.id, s.festival_id, f.name AS festival_name, s.name, s.category, s.location, s.status,
+	s.commission_percent, s.card_fee_percent, f.timezone, f.day_starts_at, f.start_date, f.end_date`
+
+func (r *repository) GetStand(ctx context.Context, standID uuid.UUID) (*OwnedStand, error) {
+	var stands []OwnedStand
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT`+ownedStandColumns+`
+		FROM public.stands s
+		INNER JOIN public.festivals f ON f.id = s.festival_id
+		WHERE s.id = ?`,
+		standID,
+	).Scan(&stands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stand: %w", err)
+	}
+	if len(stands) == 0 {
+		return nil, nil
+	}
+	return &stands[0], nil
+}
+
+func (r *repository) ListOwnedStands(ctx context.Context, userID uuid.UUID) ([]OwnedStand, error) {
+	var stands []OwnedStand
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT`+ownedStandColumns+`
+		FROM public.stand_owners o
+		INNER JOIN public.stands s ON s.id = o.stand_id
+		INNER JOIN public.festivals f ON f.id = s.festival_id
+		WHERE o.user_id = ?
+		ORDER BY f.start_date DESC, s.name`,
+		userID,
+	).Scan(&stands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list owned stands: %w", err)
+	}
+	return stands, nil
+}
+
+func (r *repository) ListFestivalStands(ctx context.Context, festivalID uuid.UUID) ([]OwnedStand, error) {
+	var stands []OwnedStand
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT`+ownedStandColumns+`
+		FROM public.stands s
+		INNER JOIN public.festivals f ON f.id = s.festival_id
+		WHERE s.festival_id = ?
+		ORDER BY s.name`,
+		festivalID,
+	).Scan(&stands).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list festival stands: %w", err)
+	}
+	return stands, nil
+}
+
+// GetDailySales sums the orders of a stand from from (inclusive) to to (exclusive) per
+// operational day of cal. Refunds count on the day of the refunded order.
+func (r *repository) GetDailySales(ctx context.Context, standID uuid.UUID, from, to time.Time, cal tz.Calendar) ([]DailySales, error) {
+	var days []DailySales
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			to_char((o.created_at AT TIME ZONE ?) - make_interval(mins => ?), 'YYYY-MM-DD') AS date,
+			COUNT(*) AS orders,
+			COALESCE(SUM(o.total_amount), 0) AS gross_sales,
+			COALESCE(SUM(o.total_amount) FILTER (WHERE o.status = 'REFUNDED'), 0) AS refunds
+		FROM public.orders o
+		WHERE o.stand_id = ? AND o.status IN ('PAID', 'REFUNDED')
+			AND o.created_at >= ? AND o.created_at < ?
+		GROUP BY 1
+		ORDER BY 1`,
+		cal.Location.String(), cal.Offset(), standID, from, to,
+	).Scan(&days).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily sales: %w", err)
+	}
+	return days, nil
+}
+
+// GetDailyCosts sums the orders of the stands of a festival like GetDailySales, with
+// the card sales and the cost of goods of the paid orders. Items cost what their product
+// did when ordered.
+func (r *repository) GetDailyCosts(ctx context.Context, festivalID uuid.UUID, from, to time.Time, cal tz.Calendar) ([]StandDailyCosts, error) {
+	var days []StandDailyCosts
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.stand_id,
+			to_char((o.created_at AT TIME ZONE ?) - make_interval(mins => ?), 'YYYY-MM-DD') AS date,
+			COUNT(*) AS orders,
+			COALESCE(SUM(o.total_amount), 0) AS gross_sales,
+			COALESCE(SUM(o.total_amount) FILTER (WHERE o.status = 'REFUNDED'), 0) AS refunds,
+			COALESCE(SUM(o.total_amount) FILTER (WHERE o.status = 'PAID' AND o.payment_method = 'card'), 0) AS card_sales,
+			COALESCE(SUM(c.cost_of_goods) FILTER (WHERE o.status = 'PAID'), 0) AS cost_of_goods,
+			COALESCE(SUM(c.uncosted_sales) FILTER (WHERE o.status = 'PAID'), 0) AS uncosted_sales
+		FROM public.orders o
+		INNER JOIN public.stands s ON s.id = o.stand_id
+		CROSS JOIN LATERAL (
+			SELECT
+				COALESCE(SUM((item->>'quantity')::bigint * (item->>'unitCost')::bigint), 0) AS cost_of_goods,
+				COALESCE(SUM((item->>'totalPrice')::bigint) FILTER (WHERE item->>'unitCost' IS NULL), 0) AS uncosted_sales
+			FROM jsonb_array_elements(o.items) item
+		) c
+		WHERE s.festival_id = ? AND o.status IN ('PAID', 'REFUNDED')
+			AND o.created_at >= ? AND o.created_at < ?
+		GROUP BY 1, 2
+		ORDER BY 1, 2`,
+		cal.Location.String(), cal.Offset(), festivalID, from, to,
+	).Scan(&days).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily costs: %w", err)
+	}
+	return days, nil
+}
+
+func (r *repository) GetHourlySales(ctx context.Context, standID uuid.UUID, from, to time.Time) ([]HourlySales, error) {
+	var hours []HourlySales
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			date_trunc('hour', o.created_at) AS hour,
+			COUNT(*) AS orders,
+			COALESCE(SUM(o.total_amount), 0) AS revenue
+		FROM public.orders o
+		WHERE o.stand_id = ? AND o.status = 'PAID'
+			AND o.created_at >= ? AND o.created_at < ?
+		GROUP BY 1
+		ORDER BY 1`,
+		standID, from, to,
+	).Scan(&hours).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly sales: %w", err)
+	}
+	return hours, nil
+}
+
+func (r *repository) GetTopProducts(ctx context.Context, standID uuid.UUID, from, to time.Time, limit int) ([]ProductSales, error) {
+	var products []ProductSales
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			(item->>'productId')::uuid AS product_id,
+			MAX(item->>'productName') AS name,
+			COALESCE(SUM((item->>'quantity')::bigint), 0) AS quantity,
+			COALESCE(SUM((item->>'totalPrice')::bigint), 0) AS revenue
+		FROM public.orders o
+		CROSS JOIN LATERAL jsonb_array_elements(o.items) item
+		WHERE o.stand_id = ? AND o.status = 'PAID'
+			AND o.created_at >= ? AND o.created_at < ?
+		GROUP BY 1
+		ORDER BY revenue DESC
+		LIMIT ?`,
+		standID, from, to, limit,
+	).Scan(&products).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+	return products, nil
+}
+
+func (r *repository) GetRecentOrders(ctx context.Context, standID uuid.UUID, limit int) ([]RecentOrder, error) {
+	var orders []RecentOrder
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT o.id, o.total_amount, o.status, o.payment_method, o.created_at
+		FROM public.orders o
+		WHERE o.stand_id = ? AND o.status IN ('PAID', 'REFUNDED')
+		ORDER BY o.created_at DESC
+		LIMIT ?`,
+		standID, limit,
+	).Scan(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *repository) CreatePayout(ctx context.Context, payout *Payout) error {
+	if err := r.db.WithContext(ctx).Create(payout).Error; err != nil {
+		return fmt.Errorf("failed to create payout: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetPayout(ctx context.Context, festivalID, id uuid.UUID) (*Payout, error) {
+	var payout Payout
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&payout).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get payout: %w", err)
+	}
+	return &payout, nil
+}
+
+func (r *repository) UpdatePayout(ctx context.Context, payout *Payout) error {
+	if err := r.db.WithContext(ctx).Save(payout).Error; err != nil {
+		return fmt.Errorf("failed to update payout: %w", err)
+	}
+	return nil
+}
+
+// ListPayouts lists the payouts of a festival, or of one of its stands, latest period first
+func (r *repository) ListPayouts(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Payout, error) {
+	var payouts []Payout
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ?", *standID)
+	}
+	if err := query.Order("period_start DESC, created_at DESC").Find(&payouts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list payouts: %w", err)
+	}
+	return payouts, nil
+}
+
+// CreateCommissionRule creates a rule along with the adjustments correcting the orders
+// it covers from before its creation
+func (r *repository) CreateCommissionRule(ctx context.Context, rule *CommissionRule, adjustments []SettlementAdjustment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rule).Error; err != nil {
+			return fmt.Errorf("failed to create commission rule: %w", err)
+		}
+		if len(adjustments) > 0 {
+			if err := tx.Create(&adjustments).Error; err != nil {
+				return fmt.Errorf("failed to create settlement adjustments: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetCommissionRule(ctx context.Context, festivalID, id uuid.UUID) (*CommissionRule, error) {
+	var rule CommissionRule
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get commission rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// ListCommissionRules lists the rules of a festival, or those applying to one of its
+// stands, festival-wide rules included, in window order
+func (r *repository) ListCommissionRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]CommissionRule, error) {
+	var rules []CommissionRule
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ? OR stand_id IS NULL", *standID)
+	}
+	if err := query.Order("starts_at, created_at").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list commission rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *repository) DeleteCommissionRule(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&CommissionRule{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete commission rule: %w", err)
+	}
+	return nil
+}
+
+// GetRuleSales sums the orders of the stands of a festival, or of one of them, taken
+// under a commission rule like GetDailySales, per rule. An order falls under the stand
+// rule covering it, else under the festival-wide one, as long as the rule was created
+// before the order.
+func (r *repository) GetRuleSales(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time, cal tz.Calendar) ([]RuleSales, error) {
+	args := []interface{}{cal.Location.String(), cal.Offset(), festivalID, from, to}
+	standFilter := ""
+	if standID != nil {
+		standFilter = "AND o.stand_id = ?"
+		args = append(args, *standID)
+	}
+
+	var sales []RuleSales
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			o.stand_id,
+			to_char((o.created_at AT TIME ZONE ?) - make_interval(mins => ?), 'YYYY-MM-DD') AS date,
+			cr.id AS rule_id,
+			cr.label,
+			cr.percent,
+			cr.stand_id IS NOT NULL AS stand_rule,
+			COUNT(*) AS orders,
+			COALESCE(SUM(o.total_amount), 0) AS gross_sales,
+			COALESCE(SUM(o.total_amount) FILTER (WHERE o.status = 'REFUNDED'), 0) AS refunds
+		FROM public.orders o
+		INNER JOIN public.stands s ON s.id = o.stand_id
+		CROSS JOIN LATERAL (
+			SELECT r.id, r.label, r.percent, r.stand_id
+			FROM public.commission_rules r
+			WHERE r.festival_id = s.festival_id
+				AND (r.stand_id = o.stand_id OR r.stand_id IS NULL)
+				AND r.starts_at <= o.created_at AND o.created_at < r.ends_at
+				AND r.created_at <= o.created_at
+			ORDER BY r.stand_id IS NULL
+			LIMIT 1
+		) cr
+		WHERE s.festival_id = ? AND o.status IN ('PAID', 'REFUNDED')
+			AND o.created_at >= ? AND o.created_at < ? `+standFilter+`
+		GROUP BY 1, 2, 3, 4, 5, 6
+		ORDER BY 1, 2`,
+		args...,
+	).Scan(&sales).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule sales: %w", err)
+	}
+	return sales, nil
+}
+
+func (r *repository) CreateAdjustment(ctx context.Context, adjustment *SettlementAdjustment) error {
+	if err := r.db.WithContext(ctx).Create(adjustment).Error; err != nil {
+		return fmt.Errorf("failed to create settlement adjustment: %w", err)
+	}
+	return nil
+}
+
+// ListAdjustments lists the adjustments of a festival, or of one of its stands, oldest
+// day first
+func (r *repository) ListAdjustments(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]SettlementAdjustment, error) {
+	var adjustments []SettlementAdjustment
+	query := r.db.WithContext(ctx).Where("festival_id = ?", festivalID)
+	if standID != nil {
+		query = query.Where("stand_id = ?", *standID)
+	}
+	if err := query.Order("date, created_at").Find(&adjustments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list settlement adjustments: %w", err)
+	}
+	return adjustments, nil
+}
+
+func (r *repository) CreateMenuChange(ctx context.Context, change *MenuChange) error {
+	if err := r.db.WithContext(ctx).Create(change).Error; err != nil {
+		return fmt.Errorf("failed to create menu change: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetMenuChange(ctx context.Context, id uuid.UUID) (*MenuChange, error) {
+	var change MenuChange
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get menu change: %w", err)
+	}
+	return &change, nil
+}
+
+// GetProductDraft returns the draft update of a product of the stand, if any
+func (r *repository) GetProductDraft(ctx context.Context, standID, productID uuid.UUID) (*MenuChange, error) {
+	var change MenuChange
+	err := r.db.WithContext(ctx).
+		Where("stand_id = ? AND product_id = ? AND action = ? AND status = ?", standID, productID, MenuChangeUpdate, MenuChangeDraft).
+		First(&change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get product draft: %w", err)
+	}
+	return &change, nil
+}
+
+func (r *repository) UpdateMenuChange(ctx context.Context, change *MenuChange) error {
+	if err := r.db.WithContext(ctx).Save(change).Error; err != nil {
+		return fmt.Errorf("failed to update menu change: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteMenuChange(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&MenuChange{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete menu change: %w", err)
+	}
+	return nil
+}
+
+// ListMenuChanges lists menu changes, the longest waiting first
+func (r *repository) ListMenuChanges(ctx context.Context, filter MenuChangeFilter) ([]MenuChange, int64, error) {
+	query := r.db.WithContext(ctx).Model(&MenuChange{})
+	if filter.FestivalID != nil {
+		query = query.Where("festival_id = ?", *filter.FestivalID)
+	}
+	if filter.StandID != nil {
+		query = query.Where("stand_id = ?", *filter.StandID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count menu changes: %w", err)
+	}
+
+	var changes []MenuChange
+	err := query.
+		Order("COALESCE(submitted_at, created_at), created_at").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&changes).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list menu changes: %w", err)
+	}
+	return changes, total, nil
+}
+
+func (r *repository) ListOwnerContacts(ctx context.Context, standID uuid.UUID) ([]OwnerContact, error) {
+	var contacts []OwnerContact
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT u.email, COALESCE(p.preferred_language, '') AS locale
+		FROM public.stand_owners o
+		INNER JOIN public.users u ON u.id = o.user_id
+		LEFT JOIN public.user_notification_preferences p ON p.user_id = o.user_id
+		WHERE o.stand_id = ? AND u.email <> ''
+		ORDER BY o.created_at`,
+		standID,
+	).Scan(&contacts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stand owner contacts: %w", err)
+	}
+	return contacts, nil
+}
@@ -0,0 +1,198 @@
+package vendorportal
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreateOwner(ctx context.Context, owner *StandOwner) error {
+	args := m.Called(ctx, owner)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetOwner(ctx context.Context, festivalID, id uuid.UUID) (*StandOwner, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandOwner), args.Error(1)
+}
+
+func (m *MockRepository) GetOwnerByStand(ctx context.Context, standID, userID uuid.UUID) (*StandOwner, error) {
+	args := m.Called(ctx, standID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*StandOwner), args.Error(1)
+}
+
+func (m *MockRepository) ListOwners(ctx context.Context, festivalID uuid.UUID) ([]StandOwner, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]StandOwner), args.Error(1)
+}
+
+func (m *MockRepository) DeleteOwner(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetStand(ctx context.Context, standID uuid.UUID) (*OwnedStand, error) {
+	args := m.Called(ctx, standID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OwnedStand), args.Error(1)
+}
+
+func (m *MockRepository) ListOwnedStands(ctx context.Context, userID uuid.UUID) ([]OwnedStand, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]OwnedStand), args.Error(1)
+}
+
+func (m *MockRepository) ListFestivalStands(ctx context.Context, festivalID uuid.UUID) ([]OwnedStand, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]OwnedStand), args.Error(1)
+}
+
+func (m *MockRepository) GetDailySales(ctx context.Context, standID uuid.UUID, from, to time.Time, cal tz.Calendar) ([]DailySales, error) {
+	args := m.Called(ctx, standID, from, to, cal)
+	return args.Get(0).([]DailySales), args.Error(1)
+}
+
+func (m *MockRepository) GetHourlySales(ctx context.Context, standID uuid.UUID, from, to time.Time) ([]HourlySales, error) {
+	args := m.Called(ctx, standID, from, to)
+	return args.Get(0).([]HourlySales), args.Error(1)
+}
+
+func (m *MockRepository) GetTopProducts(ctx context.Context, standID uuid.UUID, from, to time.Time, limit int) ([]ProductSales, error) {
+	args := m.Called(ctx, standID, from, to, limit)
+	return args.Get(0).([]ProductSales), args.Error(1)
+}
+
+func (m *MockRepository) GetRecentOrders(ctx context.Context, standID uuid.UUID, limit int) ([]RecentOrder, error) {
+	args := m.Called(ctx, standID, limit)
+	return args.Get(0).([]RecentOrder), args.Error(1)
+}
+
+func (m *MockRepository) GetDailyCosts(ctx context.Context, festivalID uuid.UUID, from, to time.Time, cal tz.Calendar) ([]StandDailyCosts, error) {
+	args := m.Called(ctx, festivalID, from, to, cal)
+	return args.Get(0).([]StandDailyCosts), args.Error(1)
+}
+
+func (m *MockRepository) CreatePayout(ctx context.Context, payout *Payout) error {
+	args := m.Called(ctx, payout)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPayout(ctx context.Context, festivalID, id uuid.UUID) (*Payout, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Payout), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePayout(ctx context.Context, payout *Payout) error {
+	args := m.Called(ctx, payout)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListPayouts(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Payout, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]Payout), args.Error(1)
+}
+
+func (m *MockRepository) CreateCommissionRule(ctx context.Context, rule *CommissionRule, adjustments []SettlementAdjustment) error {
+	args := m.Called(ctx, rule, adjustments)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCommissionRule(ctx context.Context, festivalID, id uuid.UUID) (*CommissionRule, error) {
+	args := m.Called(ctx, festivalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CommissionRule), args.Error(1)
+}
+
+func (m *MockRepository) ListCommissionRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]CommissionRule, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]CommissionRule), args.Error(1)
+}
+
+func (m *MockRepository) DeleteCommissionRule(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRuleSales(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, from, to time.Time, cal tz.Calendar) ([]RuleSales, error) {
+	args := m.Called(ctx, festivalID, standID, from, to, cal)
+	return args.Get(0).([]RuleSales), args.Error(1)
+}
+
+func (m *MockRepository) CreateAdjustment(ctx context.Context, adjustment *SettlementAdjustment) error {
+	args := m.Called(ctx, adjustment)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListAdjustments(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]SettlementAdjustment, error) {
+	args := m.Called(ctx, festivalID, standID)
+	return args.Get(0).([]SettlementAdjustment), args.Error(1)
+}
+
+func (m *MockRepository) CreateMenuChange(ctx context.Context, change *MenuChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetMenuChange(ctx context.Context, id uuid.UUID) (*MenuChange, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MenuChange), args.Error(1)
+}
+
+func (m *MockRepository) GetProductDraft(ctx context.Context, standID, productID uuid.UUID) (*MenuChange, error) {
+	args := m.Called(ctx, standID, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MenuChange), args.Error(1)
+}
+
+func (m *MockRepository) UpdateMenuChange(ctx context.Context, change *MenuChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteMenuChange(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListMenuChanges(ctx context.Context, filter MenuChangeFilter) ([]MenuChange, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]MenuChange), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) ListOwnerContacts(ctx context.Context, standID uuid.UUID) ([]OwnerContact, error) {
+	args := m.Called(ctx, standID)
+	return args.Get(0).([]OwnerContact), args.Error(1)
+}
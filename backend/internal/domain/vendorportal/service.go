@@ -0,0 +1,1089 @@
+package vendorportal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// TypePublishMenuChange is the worker task publishing an approved menu change at its
+// publish time
+const TypePublishMenuChange = "vendorportal:publish_menu_change"
+
+// MenuChangeTaskPayload identifies the menu change published by a task
+type MenuChangeTaskPayload struct {
+	ChangeID uuid.UUID `json:"changeId"`
+}
+
+// NewPublishMenuChangeTask creates the task publishing a scheduled menu change
+func NewPublishMenuChangeTask(changeID uuid.UUID) (*asynq.Task, error) {
+	data, err := json.Marshal(MenuChangeTaskPayload{ChangeID: changeID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypePublishMenuChange, data), nil
+}
+
+// ProductManager manages the products of a stand; satisfied by product.Service
+type ProductManager interface {
+	Create(ctx context.Context, req product.CreateProductRequest) (*product.Product, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*product.Product, error)
+	List(ctx context.Context, standID uuid.UUID, page, perPage int) ([]product.Product, int64, error)
+	Update(ctx context.Context, id uuid.UUID, req product.UpdateProductRequest) (*product.Product, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// StaffManager manages the staff of a stand; satisfied by stand.Service
+type StaffManager interface {
+	GetStaff(ctx context.Context, standID uuid.UUID) ([]stand.StandStaff, error)
+	AssignStaff(ctx context.Context, standID uuid.UUID, req stand.AssignStaffRequest) (*stand.StandStaff, error)
+	RemoveStaff(ctx context.Context, standID, userID uuid.UUID) error
+}
+
+// Notifier tells stand owners about the review of their menu changes; satisfied by
+// jobs.EmailQueue
+type Notifier interface {
+	NotifyMenuChange(ctx context.Context, notice MenuChangeNotice) error
+}
+
+type Service struct {
+	repo        Repository
+	products    ProductManager
+	staff       StaffManager
+	queueClient *queue.Client
+	notifier    Notifier
+	now         func() time.Time
+}
+
+func NewService(repo Repository, products ProductManager, staff StaffManager) *Service {
+	return &Service{repo: repo, products: products, staff: staff, now: time.Now}
+}
+
+// SetQueue enables menu changes approved for a later publication
+func (s *Service) SetQueue(queueClient *queue.Client) {
+	s.queueClient = queueClient
+}
+
+// SetNotifier notifies stand owners when their menu changes are approved or rejected
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// IsStandOwner checks if a user owns a stand; satisfies middleware.StandOwnershipChecker
+func (s *Service) IsStandOwner(ctx context.Context, userID, standID string) (bool, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return false, nil
+	}
+	sid, err := uuid.Parse(standID)
+	if err != nil {
+		return false, nil
+	}
+	owner, err := s.repo.GetOwnerByStand(ctx, sid, uid)
+	if err != nil {
+		return false, err
+	}
+	return owner != nil, nil
+}
+
+// Stand owners, managed by organizers
+
+// AssignOwner makes a vendor account owner of a stand of the festival
+func (s *Service) AssignOwner(ctx context.Context, festivalID uuid.UUID, req AssignOwnerRequest, createdBy *uuid.UUID) (*StandOwner, error) {
+	if _, err := s.festivalStand(ctx, festivalID, req.StandID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetOwnerByStand(ctx, req.StandID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyOwner
+	}
+
+	owner := &StandOwner{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    req.StandID,
+		UserID:     req.UserID,
+		CreatedBy:  createdBy,
+		CreatedAt:  s.now(),
+	}
+	if err := s.repo.CreateOwner(ctx, owner); err != nil {
+		return nil, err
+	}
+	return owner, nil
+}
+
+func (s *Service) ListOwners(ctx context.Context, festivalID uuid.UUID) ([]StandOwner, error) {
+	return s.repo.ListOwners(ctx, festivalID)
+}
+
+// RemoveOwner revokes the vendor portal of a stand owner
+func (s *Service) RemoveOwner(ctx context.Context, festivalID, id uuid.UUID) error {
+	owner, err := s.repo.GetOwner(ctx, festivalID, id)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		return ErrOwnerNotFound
+	}
+	return s.repo.DeleteOwner(ctx, id)
+}
+
+// Payouts, managed by organizers
+
+// CreatePayout schedules a payout to the owners of a stand, of the statement balance
+// of the period unless an amount is given
+func (s *Service) CreatePayout(ctx context.Context, festivalID uuid.UUID, req CreatePayoutRequest, createdBy *uuid.UUID) (*Payout, error) {
+	st, err := s.festivalStand(ctx, festivalID, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.PeriodEnd.After(req.PeriodStart) {
+		return nil, ErrInvalidPeriod
+	}
+
+	var amount int64
+	if req.Amount != nil {
+		amount = *req.Amount
+	} else {
+		statement, err := s.statement(ctx, st, req.PeriodStart, req.PeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+		amount = statement.Balance
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidPayoutAmount
+	}
+
+	now := s.now()
+	payout := &Payout{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		StandID:     req.StandID,
+		PeriodStart: req.PeriodStart,
+		PeriodEnd:   req.PeriodEnd,
+		Amount:      amount,
+		Status:      PayoutStatusPending,
+		Reference:   req.Reference,
+		Notes:       req.Notes,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreatePayout(ctx, payout); err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+func (s *Service) ListPayouts(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]Payout, error) {
+	return s.repo.ListPayouts(ctx, festivalID, standID)
+}
+
+// UpdatePayout records the outcome of a payout
+func (s *Service) UpdatePayout(ctx context.Context, festivalID, id uuid.UUID, req UpdatePayoutRequest) (*Payout, error) {
+	payout, err := s.repo.GetPayout(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if payout == nil {
+		return nil, ErrPayoutNotFound
+	}
+
+	if req.Status != nil {
+		if !req.Status.IsValid() {
+			return nil, ErrInvalidPayoutStatus
+		}
+		if *req.Status == PayoutStatusPaid && payout.Status != PayoutStatusPaid {
+			paidAt := s.now()
+			payout.PaidAt = &paidAt
+		} else if *req.Status != PayoutStatusPaid {
+			payout.PaidAt = nil
+		}
+		payout.Status = *req.Status
+	}
+	if req.Reference != nil {
+		payout.Reference = *req.Reference
+	}
+	if req.Notes != nil {
+		payout.Notes = *req.Notes
+	}
+
+	payout.UpdatedAt = s.now()
+	if err := s.repo.UpdatePayout(ctx, payout); err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// Profitability, for organizers
+
+// GetProfitability returns the profitability of every stand of the festival from the
+// start of from to the end of until, both operational days; the period defaults to the
+// festival days
+func (s *Service) GetProfitability(ctx context.Context, festivalID uuid.UUID, from, until *time.Time) (*ProfitabilityReport, error) {
+	stands, err := s.repo.ListFestivalStands(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProfitabilityReport{
+		FestivalID:  festivalID,
+		Vendors:     make([]VendorProfitability, 0, len(stands)),
+		GeneratedAt: s.now(),
+	}
+	if len(stands) == 0 {
+		return report, nil
+	}
+
+	// The stands share the calendar and the dates of their festival
+	cal := stands[0].Calendar()
+	start, _ := cal.Bounds(stands[0].StartDate)
+	_, end := cal.Bounds(stands[0].EndDate)
+	if from != nil {
+		start, _ = cal.Bounds(*from)
+	}
+	if until != nil {
+		_, end = cal.Bounds(*until)
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidPeriod
+	}
+	if end.Sub(start) > MaxStatementDays*24*time.Hour+time.Hour {
+		return nil, ErrPeriodTooLong
+	}
+	report.PeriodStart, report.PeriodEnd = start, end
+
+	days, err := s.repo.GetDailyCosts(ctx, festivalID, start, end, cal)
+	if err != nil {
+		return nil, err
+	}
+	ruleSales, err := s.repo.GetRuleSales(ctx, festivalID, nil, start, end, cal)
+	if err != nil {
+		return nil, err
+	}
+	attachRuleSales(days, ruleSales)
+	daysByStand := make(map[uuid.UUID][]StandDailyCosts, len(stands))
+	for _, day := range days {
+		daysByStand[day.StandID] = append(daysByStand[day.StandID], day)
+	}
+
+	for _, st := range stands {
+		vendor := BuildVendorProfitability(daysByStand[st.ID], st.CommissionPercent, st.CardFeePercent)
+		vendor.StandID = st.ID
+		vendor.StandName = st.Name
+		vendor.CommissionPercent = st.CommissionPercent
+		vendor.CardFeePercent = st.CardFeePercent
+		report.Vendors = append(report.Vendors, vendor)
+
+		report.Totals.Orders += vendor.Orders
+		report.Totals.NetSales += vendor.NetSales
+		report.Totals.UncostedSales += vendor.UncostedSales
+		report.Totals.CostOfGoods += vendor.CostOfGoods
+		report.Totals.Commission += vendor.Commission
+		report.Totals.Fees += vendor.Fees
+		report.Totals.Profit += vendor.Profit
+	}
+	report.Totals.MarginPercent = marginPercent(report.Totals.Profit, report.Totals.NetSales)
+
+	sort.SliceStable(report.Vendors, func(i, j int) bool {
+		return report.Vendors[i].Profit > report.Vendors[j].Profit
+	})
+	return report, nil
+}
+
+// Vendor portal, scoped to a stand the caller owns by middleware.RequireStandOwnership
+
+func (s *Service) ListMyStands(ctx context.Context, userID uuid.UUID) ([]OwnedStand, error) {
+	return s.repo.ListOwnedStands(ctx, userID)
+}
+
+func (s *Service) GetStand(ctx context.Context, standID uuid.UUID) (*OwnedStand, error) {
+	st, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, ErrStandNotFound
+	}
+	return st, nil
+}
+
+func (s *Service) ListProducts(ctx context.Context, standID uuid.UUID, page, perPage int) ([]product.Product, int64, error) {
+	return s.products.List(ctx, standID, page, perPage)
+}
+
+// CreateProduct drafts the addition of a product to the stand
+func (s *Service) CreateProduct(ctx context.Context, standID uuid.UUID, req CreateProductRequest, userID *uuid.UUID) (*MenuChange, error) {
+	st, err := s.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	change := &MenuChange{
+		ID:          uuid.New(),
+		FestivalID:  st.FestivalID,
+		StandID:     standID,
+		Action:      MenuChangeCreate,
+		ProductName: req.Name,
+		Create:      &req,
+		Status:      MenuChangeDraft,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateMenuChange(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// UpdateProduct applies the stock, status and cost price of a product of the stand right
+// away, as they follow what happens at the stand, and drafts the other changes to the menu. It
+// returns the draft when there is one, merged into the open draft of the product.
+func (s *Service) UpdateProduct(ctx context.Context, standID, productID uuid.UUID, req product.UpdateProductRequest, userID *uuid.UUID) (*product.Product, *MenuChange, error) {
+	p, err := s.standProduct(ctx, standID, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	operational, menu := splitProductUpdate(req)
+	if operational.Stock != nil || operational.Status != nil || operational.CostPrice != nil {
+		if p, err = s.products.Update(ctx, productID, operational); err != nil {
+			return nil, nil, err
+		}
+	}
+	if menu == nil {
+		return p, nil, nil
+	}
+
+	change, err := s.repo.GetProductDraft(ctx, standID, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.now()
+	if change == nil {
+		price := p.Price
+		st, err := s.GetStand(ctx, standID)
+		if err != nil {
+			return nil, nil, err
+		}
+		change = &MenuChange{
+			ID:           uuid.New(),
+			FestivalID:   st.FestivalID,
+			StandID:      standID,
+			ProductID:    &productID,
+			Action:       MenuChangeUpdate,
+			ProductName:  p.Name,
+			CurrentPrice: &price,
+			Update:       menu,
+			Status:       MenuChangeDraft,
+			CreatedBy:    userID,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := s.repo.CreateMenuChange(ctx, change); err != nil {
+			return nil, nil, err
+		}
+		return p, change, nil
+	}
+
+	change.Update = mergeProductUpdates(change.Update, menu)
+	change.UpdatedAt = now
+	if err := s.repo.UpdateMenuChange(ctx, change); err != nil {
+		return nil, nil, err
+	}
+	return p, change, nil
+}
+
+// DeleteProduct drafts the removal of a product from the menu of the stand
+func (s *Service) DeleteProduct(ctx context.Context, standID, productID uuid.UUID, userID *uuid.UUID) (*MenuChange, error) {
+	p, err := s.standProduct(ctx, standID, productID)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	price := p.Price
+	change := &MenuChange{
+		ID:           uuid.New(),
+		FestivalID:   st.FestivalID,
+		StandID:      standID,
+		ProductID:    &productID,
+		Action:       MenuChangeDelete,
+		ProductName:  p.Name,
+		CurrentPrice: &price,
+		Status:       MenuChangeDraft,
+		CreatedBy:    userID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.repo.CreateMenuChange(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// Menu changes of a stand, drafted by its vendor and approved by organizers
+
+// ListStandMenuChanges lists the menu changes of the stand, optionally in one status
+func (s *Service) ListStandMenuChanges(ctx context.Context, standID uuid.UUID, status MenuChangeStatus, offset, limit int) ([]MenuChange, int64, error) {
+	if status != "" && !status.IsValid() {
+		return nil, 0, ErrInvalidMenuChangeStatus
+	}
+	return s.repo.ListMenuChanges(ctx, MenuChangeFilter{StandID: &standID, Status: status, Offset: offset, Limit: limit})
+}
+
+// SubmitMenuChange submits a draft menu change of the stand for approval, to be
+// published no earlier than publishAt when given
+func (s *Service) SubmitMenuChange(ctx context.Context, standID, id uuid.UUID, req SubmitMenuChangeRequest, userID *uuid.UUID) (*MenuChange, error) {
+	change, err := s.standMenuChange(ctx, standID, id)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != MenuChangeDraft {
+		return nil, ErrMenuChangeNotDraft
+	}
+
+	now := s.now()
+	if req.PublishAt != nil && !req.PublishAt.After(now) {
+		return nil, ErrPublishAtInPast
+	}
+	change.Status = MenuChangePending
+	change.PublishAt = req.PublishAt
+	change.SubmittedBy = userID
+	change.SubmittedAt = &now
+	change.UpdatedAt = now
+	if err := s.repo.UpdateMenuChange(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// DiscardMenuChange drops a menu change of the stand not reviewed yet
+func (s *Service) DiscardMenuChange(ctx context.Context, standID, id uuid.UUID) error {
+	change, err := s.standMenuChange(ctx, standID, id)
+	if err != nil {
+		return err
+	}
+	if change.Status != MenuChangeDraft && change.Status != MenuChangePending {
+		return ErrMenuChangeClosed
+	}
+	return s.repo.DeleteMenuChange(ctx, id)
+}
+
+// ListMenuChanges lists the menu changes of the festival submitted to organizers, the
+// approval queue by default
+func (s *Service) ListMenuChanges(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID, status MenuChangeStatus, offset, limit int) ([]MenuChange, int64, error) {
+	if status == "" {
+		status = MenuChangePending
+	}
+	if !status.IsValid() || status == MenuChangeDraft {
+		return nil, 0, ErrInvalidMenuChangeStatus
+	}
+	return s.repo.ListMenuChanges(ctx, MenuChangeFilter{FestivalID: &festivalID, StandID: standID, Status: status, Offset: offset, Limit: limit})
+}
+
+// ApproveMenuChange approves a submitted menu change of the festival. It is published
+// right away, or by the worker at the time set by the organizer or requested by the
+// vendor.
+func (s *Service) ApproveMenuChange(ctx context.Context, festivalID, id uuid.UUID, req ApproveMenuChangeRequest, reviewerID *uuid.UUID) (*MenuChange, error) {
+	change, err := s.pendingMenuChange(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if req.PublishAt != nil {
+		if !req.PublishAt.After(now) {
+			return nil, ErrPublishAtInPast
+		}
+		change.PublishAt = req.PublishAt
+	}
+	change.ReviewedBy = reviewerID
+	change.ReviewedAt = &now
+	change.ReviewNote = req.Note
+
+	if change.PublishAt != nil && change.PublishAt.After(now) {
+		if s.queueClient == nil {
+			return nil, ErrSchedulingUnavailable
+		}
+		if err := s.schedulePublication(ctx, change); err != nil {
+			return nil, err
+		}
+		change.Status = MenuChangeScheduled
+		change.UpdatedAt = now
+		if err := s.repo.UpdateMenuChange(ctx, change); err != nil {
+			return nil, err
+		}
+	} else {
+		change.PublishAt = nil
+		if err := s.publish(ctx, change); err != nil {
+			return nil, err
+		}
+	}
+
+	s.notify(ctx, change, true)
+	return change, nil
+}
+
+// RejectMenuChange rejects a submitted menu change of the festival, or cancels one
+// scheduled for a later publication
+func (s *Service) RejectMenuChange(ctx context.Context, festivalID, id uuid.UUID, req RejectMenuChangeRequest, reviewerID *uuid.UUID) (*MenuChange, error) {
+	change, err := s.pendingMenuChange(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	change.Status = MenuChangeRejected
+	change.ReviewedBy = reviewerID
+	change.ReviewedAt = &now
+	change.ReviewNote = req.Note
+	change.UpdatedAt = now
+	if err := s.repo.UpdateMenuChange(ctx, change); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, change, false)
+	return change, nil
+}
+
+// PublishScheduled publishes a scheduled menu change once its publish time has come
+func (s *Service) PublishScheduled(ctx context.Context, id uuid.UUID) (*MenuChange, error) {
+	change, err := s.repo.GetMenuChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, ErrMenuChangeNotFound
+	}
+	if change.Status != MenuChangeScheduled {
+		return nil, ErrMenuChangeNotPending
+	}
+	if change.PublishAt != nil && change.PublishAt.After(s.now()) {
+		// Picked up early, e.g. by a retry scheduled before the time was moved
+		return change, s.schedulePublication(ctx, change)
+	}
+	if err := s.publish(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// HandlePublishMenuChange processes the scheduled publication task
+func (s *Service) HandlePublishMenuChange(ctx context.Context, t *asynq.Task) error {
+	var payload MenuChangeTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	_, err := s.PublishScheduled(ctx, payload.ChangeID)
+	if errors.Is(err, ErrMenuChangeNotPending) || errors.Is(err, ErrMenuChangeNotFound) {
+		// Rejected or discarded in the meantime, nothing to retry
+		log.Info().Str("menu_change_id", payload.ChangeID.String()).Msg("Skipping menu change publication task")
+		return nil
+	}
+	return err
+}
+
+// GetLiveSales returns the sales of the stand since the start of the operational day of
+// the festival
+func (s *Service) GetLiveSales(ctx context.Context, standID uuid.UUID) (*LiveSales, error) {
+	st, err := s.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	cal := st.Calendar()
+	since, until := cal.DayBounds(now)
+
+	live := &LiveSales{
+		StandID:     standID,
+		Date:        since.Format("2006-01-02"),
+		Since:       since,
+		GeneratedAt: now,
+	}
+
+	days, err := s.repo.GetDailySales(ctx, standID, since, until, cal)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		live.Orders += day.Orders
+		live.Revenue += day.GrossSales - day.Refunds
+		live.Refunds += day.Refunds
+	}
+	if live.Orders > 0 {
+		live.AverageOrder = (live.Revenue + live.Refunds) / live.Orders
+	}
+
+	if live.Hourly, err = s.repo.GetHourlySales(ctx, standID, since, until); err != nil {
+		return nil, err
+	}
+	lastHour := now.Add(-time.Hour)
+	for _, hour := range live.Hourly {
+		// The hour in progress and the one before it, so the figure does not drop to
+		// zero on the hour
+		if !hour.Hour.Add(time.Hour).Before(lastHour) {
+			live.LastHourOrders += hour.Orders
+			live.LastHourRevenue += hour.Revenue
+		}
+	}
+
+	if live.TopProducts, err = s.repo.GetTopProducts(ctx, standID, since, until, TopProductsLimit); err != nil {
+		return nil, err
+	}
+	if live.RecentOrders, err = s.repo.GetRecentOrders(ctx, standID, RecentOrdersLimit); err != nil {
+		return nil, err
+	}
+	return live, nil
+}
+
+// GetStatement returns the settlement statement of the stand from the start of from to
+// the end of until, both operational days of the festival; the period defaults to the
+// festival days
+func (s *Service) GetStatement(ctx context.Context, standID uuid.UUID, from, until *time.Time) (*Statement, error) {
+	st, err := s.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := st.Calendar()
+	start, _ := cal.Bounds(st.StartDate)
+	_, end := cal.Bounds(st.EndDate)
+	if from != nil {
+		start, _ = cal.Bounds(*from)
+	}
+	if until != nil {
+		_, end = cal.Bounds(*until)
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidPeriod
+	}
+	if end.Sub(start) > MaxStatementDays*24*time.Hour+time.Hour {
+		return nil, ErrPeriodTooLong
+	}
+
+	return s.statement(ctx, st, start, end)
+}
+
+// GetPayouts returns the payouts of the stand against what it earned to date
+func (s *Service) GetPayouts(ctx context.Context, standID uuid.UUID) (*PayoutSummary, error) {
+	st, err := s.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.dailySales(ctx, st, time.Time{}, s.now())
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := s.repo.ListAdjustments(ctx, st.FestivalID, &standID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := s.repo.ListPayouts(ctx, st.FestivalID, &standID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PayoutSummary{
+		StandID: standID,
+		Earned:  BuildStatementTotals(days, st.CommissionPercent).Payable,
+		Payouts: payouts,
+	}
+	for _, adjustment := range adjustments {
+		summary.Earned += adjustment.Amount
+	}
+	for _, payout := range payouts {
+		switch payout.Status {
+		case PayoutStatusPaid:
+			summary.Paid += payout.Amount
+		case PayoutStatusPending:
+			summary.Pending += payout.Amount
+		}
+	}
+	summary.Outstanding = summary.Earned - summary.Paid - summary.Pending
+	return summary, nil
+}
+
+func (s *Service) ListStaff(ctx context.Context, standID uuid.UUID) ([]stand.StandStaff, error) {
+	return s.staff.GetStaff(ctx, standID)
+}
+
+func (s *Service) AssignStaff(ctx context.Context, standID uuid.UUID, req stand.AssignStaffRequest) (*stand.StandStaff, error) {
+	switch req.Role {
+	case stand.StaffRoleManager, stand.StaffRoleCashier, stand.StaffRoleAssistant:
+	default:
+		return nil, ErrInvalidStaffRole
+	}
+
+	staff, err := s.staff.GetStaff(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range staff {
+		if member.UserID == req.UserID {
+			return nil, ErrAlreadyStaff
+		}
+	}
+
+	member, err := s.staff.AssignStaff(ctx, standID, req)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, ErrStandNotFound
+	}
+	return member, err
+}
+
+func (s *Service) RemoveStaff(ctx context.Context, standID, userID uuid.UUID) error {
+	err := s.staff.RemoveStaff(ctx, standID, userID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return ErrStaffNotFound
+	}
+	return err
+}
+
+// statement builds the statement of a stand from start (inclusive) to end (exclusive)
+func (s *Service) statement(ctx context.Context, st *OwnedStand, start, end time.Time) (*Statement, error) {
+	days, err := s.dailySales(ctx, st, start, end)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := s.repo.ListAdjustments(ctx, st.FestivalID, &st.ID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := s.repo.ListPayouts(ctx, st.FestivalID, &st.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		StandID:           st.ID,
+		StandName:         st.Name,
+		PeriodStart:       start,
+		PeriodEnd:         end,
+		CommissionPercent: st.CommissionPercent,
+		Days:              make([]StatementDay, len(days)),
+		Adjustments:       []SettlementAdjustment{},
+		GeneratedAt:       s.now(),
+	}
+	for i, day := range days {
+		statement.Days[i] = BuildStatementDay(day, st.CommissionPercent)
+	}
+	statement.Totals = BuildStatementTotals(days, st.CommissionPercent)
+
+	cal := st.Calendar()
+	for _, adjustment := range adjustments {
+		if dayStart, _ := cal.Bounds(adjustment.Date); !dayStart.Before(start) && dayStart.Before(end) {
+			statement.Adjustments = append(statement.Adjustments, adjustment)
+			statement.Totals.Adjustments += adjustment.Amount
+		}
+	}
+
+	for _, payout := range payouts {
+		if payout.Status == PayoutStatusPaid && !payout.PeriodStart.Before(start) && payout.PeriodStart.Before(end) {
+			statement.Paid += payout.Amount
+		}
+	}
+	statement.Balance = statement.Totals.Payable + statement.Totals.Adjustments - statement.Paid
+	return statement, nil
+}
+
+// BuildStatementDay computes the commission and payable of a day of sales, the sales
+// under commission rules at the percent of their rule. The commission is rounded per
+// day and rule so that statements of any period add up.
+func BuildStatementDay(sales DailySales, commissionPercent float64) StatementDay {
+	net := sales.GrossSales - sales.Refunds
+	day := StatementDay{
+		Date:       sales.Date,
+		Orders:     sales.Orders,
+		GrossSales: sales.GrossSales,
+		Refunds:    sales.Refunds,
+		NetSales:   net,
+	}
+
+	base := net
+	for _, rule := range sales.Rules {
+		ruleNet := rule.GrossSales - rule.Refunds
+		commission := int64(math.Round(float64(ruleNet) * rule.Percent / 100))
+		day.Rules = append(day.Rules, StatementRule{
+			RuleID:     rule.RuleID,
+			Label:      rule.Label,
+			Percent:    rule.Percent,
+			Orders:     rule.Orders,
+			NetSales:   ruleNet,
+			Commission: commission,
+		})
+		day.Commission += commission
+		base -= ruleNet
+	}
+	day.Commission += int64(math.Round(float64(base) * commissionPercent / 100))
+	day.Payable = net - day.Commission
+	return day
+}
+
+// BuildStatementTotals sums days of sales into statement totals
+func BuildStatementTotals(days []DailySales, commissionPercent float64) StatementTotals {
+	var totals StatementTotals
+	for _, sales := range days {
+		day := BuildStatementDay(sales, commissionPercent)
+		totals.Orders += day.Orders
+		totals.GrossSales += day.GrossSales
+		totals.Refunds += day.Refunds
+		totals.NetSales += day.NetSales
+		totals.Commission += day.Commission
+		totals.Payable += day.Payable
+	}
+	return totals
+}
+
+// BuildVendorProfitability sums days of sales of a stand into its profitability. The
+// commission matches the statement, and the card fees are rounded per day the same way.
+func BuildVendorProfitability(days []StandDailyCosts, commissionPercent, cardFeePercent float64) VendorProfitability {
+	var vendor VendorProfitability
+	for _, sales := range days {
+		day := BuildStatementDay(sales.DailySales, commissionPercent)
+		vendor.Orders += day.Orders
+		vendor.NetSales += day.NetSales
+		vendor.Commission += day.Commission
+		vendor.UncostedSales += sales.UncostedSales
+		vendor.CostOfGoods += sales.CostOfGoods
+		vendor.Fees += int64(math.Round(float64(sales.CardSales) * cardFeePercent / 100))
+	}
+	vendor.Profit = vendor.NetSales - vendor.CostOfGoods - vendor.Commission - vendor.Fees
+	vendor.MarginPercent = marginPercent(vendor.Profit, vendor.NetSales)
+	return vendor
+}
+
+// marginPercent returns profit as a percentage of sales, to one decimal
+func marginPercent(profit, sales int64) float64 {
+	if sales <= 0 {
+		return 0
+	}
+	return math.Round(float64(profit)/float64(sales)*1000) / 10
+}
+
+// festivalStand returns a stand of the festival
+func (s *Service) festivalStand(ctx context.Context, festivalID, standID uuid.UUID) (*OwnedStand, error) {
+	st, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || st.FestivalID != festivalID {
+		return nil, ErrStandNotFound
+	}
+	return st, nil
+}
+
+// standProduct returns a product of the stand
+func (s *Service) standProduct(ctx context.Context, standID, productID uuid.UUID) (*product.Product, error) {
+	p, err := s.products.GetByID(ctx, productID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.StandID != standID {
+		return nil, ErrProductNotFound
+	}
+	return p, nil
+}
+
+// standMenuChange returns a menu change of the stand
+func (s *Service) standMenuChange(ctx context.Context, standID, id uuid.UUID) (*MenuChange, error) {
+	change, err := s.repo.GetMenuChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil || change.StandID != standID {
+		return nil, ErrMenuChangeNotFound
+	}
+	return change, nil
+}
+
+// pendingMenuChange returns a menu change of the festival waiting for a review, or
+// scheduled for a later publication
+func (s *Service) pendingMenuChange(ctx context.Context, festivalID, id uuid.UUID) (*MenuChange, error) {
+	change, err := s.repo.GetMenuChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil || change.FestivalID != festivalID || change.Status == MenuChangeDraft {
+		return nil, ErrMenuChangeNotFound
+	}
+	if change.Status != MenuChangePending && change.Status != MenuChangeScheduled {
+		return nil, ErrMenuChangeNotPending
+	}
+	return change, nil
+}
+
+// publish applies an approved menu change to the products of the stand. A change whose
+// product was deleted in the meantime fails without being retried.
+func (s *Service) publish(ctx context.Context, change *MenuChange) error {
+	var err error
+	switch change.Action {
+	case MenuChangeCreate:
+		var p *product.Product
+		if p, err = s.products.Create(ctx, change.Create.ToProductRequest(change.StandID)); err == nil {
+			change.ProductID = &p.ID
+		}
+	case MenuChangeUpdate:
+		_, err = s.products.Update(ctx, *change.ProductID, *change.Update)
+	case MenuChangeDelete:
+		err = s.products.Delete(ctx, *change.ProductID)
+	}
+
+	now := s.now()
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		change.Status = MenuChangeFailed
+		change.Error = "product no longer exists"
+	case err != nil:
+		return err
+	default:
+		change.Status = MenuChangePublished
+		change.PublishedAt = &now
+	}
+	change.UpdatedAt = now
+	return s.repo.UpdateMenuChange(ctx, change)
+}
+
+// schedulePublication enqueues the publication of a change at its publish time, once
+// per publish time
+func (s *Service) schedulePublication(ctx context.Context, change *MenuChange) error {
+	task, err := NewPublishMenuChangeTask(change.ID)
+	if err != nil {
+		return err
+	}
+	_, err = s.queueClient.EnqueueScheduled(ctx, task, *change.PublishAt,
+		asynq.TaskID(fmt.Sprintf("menu_change_publish_%s_%d", change.ID, change.PublishAt.Unix())),
+		asynq.Queue(queue.QueueCritical),
+		asynq.MaxRetry(5),
+	)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to schedule menu change: %w", err)
+	}
+	return nil
+}
+
+// notify tells the owners of the stand that a menu change was reviewed. Failures are
+// logged: the review stands either way.
+func (s *Service) notify(ctx context.Context, change *MenuChange, approved bool) {
+	if s.notifier == nil {
+		return
+	}
+	st, err := s.GetStand(ctx, change.StandID)
+	if err != nil {
+		log.Warn().Err(err).Str("menu_change_id", change.ID.String()).Msg("Failed to get stand of reviewed menu change")
+		return
+	}
+	contacts, err := s.repo.ListOwnerContacts(ctx, change.StandID)
+	if err != nil {
+		log.Warn().Err(err).Str("menu_change_id", change.ID.String()).Msg("Failed to list stand owners of reviewed menu change")
+		return
+	}
+
+	for _, contact := range contacts {
+		notice := MenuChangeNotice{
+			To:           contact.Email,
+			Locale:       contact.Locale,
+			FestivalID:   change.FestivalID,
+			FestivalName: st.FestivalName,
+			StandName:    st.Name,
+			ProductName:  change.ProductName,
+			Action:       change.Action,
+			Approved:     approved,
+			Note:         change.ReviewNote,
+		}
+		if change.Status == MenuChangeScheduled {
+			notice.PublishAt = change.PublishAt
+		}
+		if err := s.notifier.NotifyMenuChange(ctx, notice); err != nil {
+			log.Warn().Err(err).Str("menu_change_id", change.ID.String()).Msg("Failed to notify stand owner of menu change review")
+		}
+	}
+}
+
+// splitProductUpdate separates the stock, status and cost price of a product update,
+// applied right away, from the changes to the menu, which need an approval. menu is nil
+// without any.
+func splitProductUpdate(req product.UpdateProductRequest) (operational product.UpdateProductRequest, menu *product.UpdateProductRequest) {
+	operational = product.UpdateProductRequest{Stock: req.Stock, Status: req.Status, CostPrice: req.CostPrice}
+	req.Stock, req.Status, req.CostPrice = nil, nil, nil
+	if req.Name == nil && req.Description == nil && req.Price == nil && req.Category == nil &&
+		req.CategoryID == nil && req.TaxClass == nil && req.ImageURL == nil && req.SKU == nil &&
+		req.SortOrder == nil && req.Tags == nil && req.NameTranslations == nil && req.DescriptionTranslations == nil {
+		return operational, nil
+	}
+	return operational, &req
+}
+
+// mergeProductUpdates applies the fields set in next over a draft update
+func mergeProductUpdates(draft, next *product.UpdateProductRequest) *product.UpdateProductRequest {
+	if draft == nil {
+		return next
+	}
+	merged := *draft
+	if next.Name != nil {
+		merged.Name = next.Name
+	}
+	if next.Description != nil {
+		merged.Description = next.Description
+	}
+	if next.NameTranslations != nil {
+		merged.NameTranslations = next.NameTranslations
+	}
+	if next.DescriptionTranslations != nil {
+		merged.DescriptionTranslations = next.DescriptionTranslations
+	}
+	if next.Price != nil {
+		merged.Price = next.Price
+	}
+	if next.Category != nil {
+		merged.Category = next.Category
+	}
+	if next.CategoryID != nil {
+		merged.CategoryID = next.CategoryID
+	}
+	if next.TaxClass != nil {
+		merged.TaxClass = next.TaxClass
+	}
+	if next.ImageURL != nil {
+		merged.ImageURL = next.ImageURL
+	}
+	if next.SKU != nil {
+		merged.SKU = next.SKU
+	}
+	if next.SortOrder != nil {
+		merged.SortOrder = next.SortOrder
+	}
+	if next.Tags != nil {
+		merged.Tags = next.Tags
+	}
+	return &merged
+}
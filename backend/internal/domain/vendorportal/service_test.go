@@ -0,0 +1,764 @@
+package vendorportal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	apperrors "github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProducts struct {
+	products map[uuid.UUID]*product.Product
+}
+
+func (f *fakeProducts) Create(ctx context.Context, req product.CreateProductRequest) (*product.Product, error) {
+	p := &product.Product{ID: uuid.New(), StandID: req.StandID, Name: req.Name, Price: req.Price}
+	f.products[p.ID] = p
+	return p, nil
+}
+
+func (f *fakeProducts) GetByID(ctx context.Context, id uuid.UUID) (*product.Product, error) {
+	if p, ok := f.products[id]; ok {
+		return p, nil
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (f *fakeProducts) List(ctx context.Context, standID uuid.UUID, page, perPage int) ([]product.Product, int64, error) {
+	return nil, 0, nil
+}
+
+func (f *fakeProducts) Update(ctx context.Context, id uuid.UUID, req product.UpdateProductRequest) (*product.Product, error) {
+	p, ok := f.products[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	if req.Name != nil {
+		p.Name = *req.Name
+	}
+	if req.Price != nil {
+		p.Price = *req.Price
+	}
+	if req.Stock != nil {
+		p.Stock = req.Stock
+	}
+	return p, nil
+}
+
+func (f *fakeProducts) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := f.products[id]; !ok {
+		return apperrors.ErrNotFound
+	}
+	delete(f.products, id)
+	return nil
+}
+
+type fakeNotifier struct {
+	notices []MenuChangeNotice
+}
+
+func (n *fakeNotifier) NotifyMenuChange(ctx context.Context, notice MenuChangeNotice) error {
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+type fakeStaff struct {
+	staff []stand.StandStaff
+}
+
+func (f *fakeStaff) GetStaff(ctx context.Context, standID uuid.UUID) ([]stand.StandStaff, error) {
+	var staff []stand.StandStaff
+	for _, s := range f.staff {
+		if s.StandID == standID {
+			staff = append(staff, s)
+		}
+	}
+	return staff, nil
+}
+
+func (f *fakeStaff) AssignStaff(ctx context.Context, standID uuid.UUID, req stand.AssignStaffRequest) (*stand.StandStaff, error) {
+	member := stand.StandStaff{ID: uuid.New(), StandID: standID, UserID: req.UserID, Role: req.Role}
+	f.staff = append(f.staff, member)
+	return &member, nil
+}
+
+func (f *fakeStaff) RemoveStaff(ctx context.Context, standID, userID uuid.UUID) error {
+	for i, s := range f.staff {
+		if s.StandID == standID && s.UserID == userID {
+			f.staff = append(f.staff[:i], f.staff[i+1:]...)
+			return nil
+		}
+	}
+	return apperrors.ErrNotFound
+}
+
+// testNow is 10:00 UTC on the second festival day
+var testNow = time.Date(2026, 7, 18, 10, 0, 0, 0, time.UTC)
+
+// testStand returns a stand of a three-day festival taking a 10% commission
+func testStand() *OwnedStand {
+	return &OwnedStand{
+		ID:                uuid.New(),
+		FestivalID:        uuid.New(),
+		FestivalName:      "Summer Festival",
+		Name:              "Burger Bar",
+		CommissionPercent: 10,
+		Timezone:          "Europe/Brussels",
+		StartDate:         time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+		EndDate:           time.Date(2026, 7, 19, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// testSales returns the sales of the stand over the two festival days so far
+func testSales() []DailySales {
+	return []DailySales{
+		{Date: "2026-07-17", Orders: 120, GrossSales: 150000, Refunds: 5000},
+		{Date: "2026-07-18", Orders: 80, GrossSales: 100005, Refunds: 0},
+	}
+}
+
+func newTestService(repo Repository) (*Service, *fakeProducts) {
+	products := &fakeProducts{products: make(map[uuid.UUID]*product.Product)}
+	service := NewService(repo, products, &fakeStaff{})
+	service.now = func() time.Time { return testNow }
+	return service, products
+}
+
+// expectMenuChange saves the next menu change created into stored, which the repository
+// serves afterwards
+func expectMenuChange(mockRepo *MockRepository) *MenuChange {
+	stored := &MenuChange{}
+	mockRepo.On("CreateMenuChange", mock.Anything, mock.AnythingOfType("*vendorportal.MenuChange")).
+		Run(func(args mock.Arguments) {
+			*stored = *args.Get(1).(*MenuChange)
+			mockRepo.On("GetMenuChange", mock.Anything, stored.ID).Return(stored, nil).Maybe()
+		}).
+		Return(nil).Once()
+	return stored
+}
+
+// expectSales serves the sales of the stand from start to end, with their part under
+// commission rules
+func expectSales(mockRepo *MockRepository, st *OwnedStand, start, end time.Time, days []DailySales, ruleSales ...RuleSales) {
+	mockRepo.On("GetDailySales", mock.Anything, st.ID, start, end, st.Calendar()).Return(days, nil)
+	mockRepo.On("GetRuleSales", mock.Anything, st.FestivalID, &st.ID, start, end, st.Calendar()).Return(ruleSales, nil)
+}
+
+func TestBuildStatementDay(t *testing.T) {
+	day := BuildStatementDay(DailySales{Date: "2026-07-17", Orders: 3, GrossSales: 1005, Refunds: 200}, 12.5)
+	assert.Equal(t, int64(805), day.NetSales)
+	assert.Equal(t, int64(101), day.Commission) // 100.625 rounded
+	assert.Equal(t, int64(704), day.Payable)
+
+	assert.Equal(t, int64(805), BuildStatementDay(DailySales{GrossSales: 1005, Refunds: 200}, 0).Payable)
+}
+
+func TestIsStandOwner(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	vendorID := uuid.New()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+
+	mockRepo.On("GetOwnerByStand", mock.Anything, st.ID, vendorID).Return(nil, nil).Once()
+	owns, err := service.IsStandOwner(ctx, vendorID.String(), st.ID.String())
+	require.NoError(t, err)
+	assert.False(t, owns)
+
+	mockRepo.On("GetOwnerByStand", mock.Anything, st.ID, vendorID).Return(nil, nil).Once()
+	mockRepo.On("CreateOwner", mock.Anything, mock.MatchedBy(func(o *StandOwner) bool {
+		return o.FestivalID == st.FestivalID && o.StandID == st.ID && o.UserID == vendorID
+	})).Return(nil).Once()
+	owner, err := service.AssignOwner(ctx, st.FestivalID, AssignOwnerRequest{StandID: st.ID, UserID: vendorID}, nil)
+	require.NoError(t, err)
+
+	mockRepo.On("GetOwnerByStand", mock.Anything, st.ID, vendorID).Return(owner, nil)
+	owns, err = service.IsStandOwner(ctx, vendorID.String(), st.ID.String())
+	require.NoError(t, err)
+	assert.True(t, owns)
+	owns, err = service.IsStandOwner(ctx, "not-a-uuid", st.ID.String())
+	require.NoError(t, err)
+	assert.False(t, owns)
+
+	_, err = service.AssignOwner(ctx, st.FestivalID, AssignOwnerRequest{StandID: st.ID, UserID: vendorID}, nil)
+	assert.ErrorIs(t, err, ErrAlreadyOwner)
+	_, err = service.AssignOwner(ctx, uuid.New(), AssignOwnerRequest{StandID: st.ID, UserID: uuid.New()}, nil)
+	assert.ErrorIs(t, err, ErrStandNotFound)
+	mockRepo.AssertNumberOfCalls(t, "CreateOwner", 1)
+
+	mockRepo.On("GetOwner", mock.Anything, st.FestivalID, owner.ID).Return(owner, nil)
+	mockRepo.On("DeleteOwner", mock.Anything, owner.ID).Return(nil).Once()
+	require.NoError(t, service.RemoveOwner(ctx, st.FestivalID, owner.ID))
+	mockRepo.On("GetOwner", mock.Anything, uuid.Nil, owner.ID).Return(nil, nil)
+	assert.ErrorIs(t, service.RemoveOwner(ctx, uuid.Nil, owner.ID), ErrOwnerNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductsScopedToStand(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, products := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+
+	other := &product.Product{ID: uuid.New(), StandID: uuid.New(), Price: 500}
+	products.products[other.ID] = other
+
+	price := int64(100)
+	_, _, err := service.UpdateProduct(ctx, st.ID, other.ID, product.UpdateProductRequest{Price: &price}, nil)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	assert.Equal(t, int64(500), other.Price)
+	_, err = service.DeleteProduct(ctx, st.ID, other.ID, nil)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	_, err = service.DeleteProduct(ctx, st.ID, uuid.New(), nil)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	mockRepo.AssertNotCalled(t, "CreateMenuChange", mock.Anything, mock.Anything)
+}
+
+func TestUpdateProduct_DraftsMenuChanges(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, products := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+
+	burger := &product.Product{ID: uuid.New(), StandID: st.ID, Name: "Cheeseburger", Price: 1200}
+	products.products[burger.ID] = burger
+
+	// Stock follows the stand and applies right away
+	stock := 40
+	p, change, err := service.UpdateProduct(ctx, st.ID, burger.ID, product.UpdateProductRequest{Stock: &stock}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, change)
+	assert.Equal(t, 40, *p.Stock)
+
+	// Prices wait for an approval, edits merging into the open draft
+	mockRepo.On("GetProductDraft", mock.Anything, st.ID, burger.ID).Return(nil, nil).Once()
+	stored := expectMenuChange(mockRepo)
+	price := int64(1400)
+	_, change, err = service.UpdateProduct(ctx, st.ID, burger.ID, product.UpdateProductRequest{Price: &price}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, change)
+	assert.Equal(t, MenuChangeDraft, change.Status)
+	assert.Equal(t, st.FestivalID, change.FestivalID)
+	assert.Equal(t, int64(1200), *change.CurrentPrice)
+	assert.Equal(t, int64(1200), burger.Price)
+
+	mockRepo.On("GetProductDraft", mock.Anything, st.ID, burger.ID).Return(stored, nil).Once()
+	mockRepo.On("UpdateMenuChange", mock.Anything, stored).Return(nil).Once()
+	name := "Double cheeseburger"
+	_, merged, err := service.UpdateProduct(ctx, st.ID, burger.ID, product.UpdateProductRequest{Name: &name}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, change.ID, merged.ID)
+	assert.Equal(t, price, *merged.Update.Price)
+	assert.Equal(t, name, *merged.Update.Name)
+	assert.Equal(t, "Cheeseburger", burger.Name)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateMenuChange", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMenuChangeWorkflow(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, products := newTestService(mockRepo)
+	ctx := context.Background()
+	notifier := &fakeNotifier{}
+	service.SetNotifier(notifier)
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+	mockRepo.On("UpdateMenuChange", mock.Anything, mock.AnythingOfType("*vendorportal.MenuChange")).Return(nil)
+	mockRepo.On("ListOwnerContacts", mock.Anything, st.ID).Return([]OwnerContact{{Email: "owner@example.com", Locale: "fr"}}, nil)
+
+	stored := expectMenuChange(mockRepo)
+	change, err := service.CreateProduct(ctx, st.ID, CreateProductRequest{Name: "Veggie burger", Price: 1100}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, change.ID)
+	assert.Empty(t, products.products, "drafts stay off the menu")
+
+	_, err = service.ApproveMenuChange(ctx, st.FestivalID, change.ID, ApproveMenuChangeRequest{}, nil)
+	assert.ErrorIs(t, err, ErrMenuChangeNotFound, "organizers do not see drafts")
+
+	past := testNow.Add(-time.Hour)
+	_, err = service.SubmitMenuChange(ctx, st.ID, change.ID, SubmitMenuChangeRequest{PublishAt: &past}, nil)
+	assert.ErrorIs(t, err, ErrPublishAtInPast)
+	_, err = service.SubmitMenuChange(ctx, st.ID, change.ID, SubmitMenuChangeRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, MenuChangePending, stored.Status)
+
+	mockRepo.On("ListMenuChanges", mock.Anything, MenuChangeFilter{FestivalID: &st.FestivalID, Status: MenuChangePending, Limit: 20}).
+		Return([]MenuChange{*stored}, int64(1), nil).Once()
+	queue, _, err := service.ListMenuChanges(ctx, st.FestivalID, nil, "", 0, 20)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+
+	approved, err := service.ApproveMenuChange(ctx, st.FestivalID, change.ID, ApproveMenuChangeRequest{Note: "OK"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, MenuChangePublished, approved.Status)
+	require.NotNil(t, approved.ProductID)
+	assert.Equal(t, int64(1100), products.products[*approved.ProductID].Price)
+
+	_, err = service.RejectMenuChange(ctx, st.FestivalID, change.ID, RejectMenuChangeRequest{Note: "Too late"}, nil)
+	assert.ErrorIs(t, err, ErrMenuChangeNotPending)
+	assert.ErrorIs(t, service.DiscardMenuChange(ctx, st.ID, change.ID), ErrMenuChangeClosed)
+
+	stored = expectMenuChange(mockRepo)
+	removal, err := service.DeleteProduct(ctx, st.ID, *approved.ProductID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, MenuChangeDelete, stored.Action)
+	_, err = service.SubmitMenuChange(ctx, st.ID, removal.ID, SubmitMenuChangeRequest{}, nil)
+	require.NoError(t, err)
+	rejected, err := service.RejectMenuChange(ctx, st.FestivalID, removal.ID, RejectMenuChangeRequest{Note: "Keep it on the menu"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, MenuChangeRejected, rejected.Status)
+	assert.Contains(t, products.products, *approved.ProductID)
+
+	require.Len(t, notifier.notices, 2)
+	assert.True(t, notifier.notices[0].Approved)
+	assert.Equal(t, "Veggie burger", notifier.notices[0].ProductName)
+	assert.Equal(t, "Burger Bar", notifier.notices[0].StandName)
+	assert.False(t, notifier.notices[1].Approved)
+	assert.Equal(t, "Keep it on the menu", notifier.notices[1].Note)
+	assert.Equal(t, "owner@example.com", notifier.notices[1].To)
+	assert.Equal(t, "fr", notifier.notices[1].Locale)
+
+	// Submitting, approving and rejecting each saved the change once
+	mockRepo.AssertNumberOfCalls(t, "UpdateMenuChange", 4)
+}
+
+func TestApproveMenuChange_Scheduled(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, products := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+	mockRepo.On("UpdateMenuChange", mock.Anything, mock.AnythingOfType("*vendorportal.MenuChange")).Return(nil)
+
+	burger := &product.Product{ID: uuid.New(), StandID: st.ID, Name: "Cheeseburger", Price: 1200}
+	products.products[burger.ID] = burger
+	mockRepo.On("GetProductDraft", mock.Anything, st.ID, burger.ID).Return(nil, nil).Once()
+	stored := expectMenuChange(mockRepo)
+	price := int64(1500)
+	_, change, err := service.UpdateProduct(ctx, st.ID, burger.ID, product.UpdateProductRequest{Price: &price}, nil)
+	require.NoError(t, err)
+	tomorrow := testNow.Add(24 * time.Hour)
+	_, err = service.SubmitMenuChange(ctx, st.ID, change.ID, SubmitMenuChangeRequest{PublishAt: &tomorrow}, nil)
+	require.NoError(t, err)
+
+	// Scheduling needs the queue, and nothing is published without it
+	_, err = service.ApproveMenuChange(ctx, st.FestivalID, change.ID, ApproveMenuChangeRequest{}, nil)
+	assert.ErrorIs(t, err, ErrSchedulingUnavailable)
+	assert.Equal(t, MenuChangePending, stored.Status)
+	assert.Equal(t, int64(1200), burger.Price)
+	mockRepo.AssertNumberOfCalls(t, "UpdateMenuChange", 1)
+
+	// The publication task applies it once due
+	stored.Status = MenuChangeScheduled
+	service.now = func() time.Time { return tomorrow }
+	published, err := service.PublishScheduled(ctx, change.ID)
+	require.NoError(t, err)
+	assert.Equal(t, MenuChangePublished, published.Status)
+	assert.Equal(t, price, burger.Price)
+
+	_, err = service.PublishScheduled(ctx, change.ID)
+	assert.ErrorIs(t, err, ErrMenuChangeNotPending)
+}
+
+func TestPublishScheduled_ProductDeleted(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+
+	productID := uuid.New()
+	price := int64(1500)
+	change := &MenuChange{
+		ID:         uuid.New(),
+		FestivalID: st.FestivalID,
+		StandID:    st.ID,
+		ProductID:  &productID,
+		Action:     MenuChangeUpdate,
+		Update:     &product.UpdateProductRequest{Price: &price},
+		Status:     MenuChangeScheduled,
+	}
+	mockRepo.On("GetMenuChange", mock.Anything, change.ID).Return(change, nil)
+	mockRepo.On("UpdateMenuChange", mock.Anything, mock.MatchedBy(func(c *MenuChange) bool {
+		return c.ID == change.ID && c.Status == MenuChangeFailed
+	})).Return(nil).Once()
+
+	failed, err := service.PublishScheduled(ctx, change.ID)
+	require.NoError(t, err)
+	assert.Equal(t, MenuChangeFailed, failed.Status)
+	assert.NotEmpty(t, failed.Error)
+
+	mockRepo.On("GetMenuChange", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err = service.PublishScheduled(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrMenuChangeNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetStatement(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+	mockRepo.On("ListAdjustments", mock.Anything, st.FestivalID, &st.ID).Return([]SettlementAdjustment{}, nil)
+
+	cal := st.Calendar()
+	start, _ := cal.Bounds(st.StartDate)
+	_, end := cal.Bounds(st.EndDate)
+	sales := testSales()
+	expectSales(mockRepo, st, start, end, sales)
+	mockRepo.On("ListPayouts", mock.Anything, st.FestivalID, &st.ID).Return([]Payout{}, nil).Twice()
+
+	statement, err := service.GetStatement(ctx, st.ID, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, statement.Days, 2)
+	assert.Equal(t, int64(245005), statement.Totals.NetSales)
+	assert.Equal(t, int64(14500+10001), statement.Totals.Commission)
+	assert.Equal(t, int64(130500+90004), statement.Totals.Payable)
+	assert.Equal(t, statement.Totals.Payable, statement.Balance)
+
+	// Paying the first day out leaves the second day's balance
+	firstDayEnd := statement.PeriodStart.AddDate(0, 0, 1)
+	expectSales(mockRepo, st, start, firstDayEnd, sales[:1])
+	mockRepo.On("CreatePayout", mock.Anything, mock.AnythingOfType("*vendorportal.Payout")).Return(nil).Once()
+	payout, err := service.CreatePayout(ctx, st.FestivalID, CreatePayoutRequest{
+		StandID:     st.ID,
+		PeriodStart: statement.PeriodStart,
+		PeriodEnd:   firstDayEnd,
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(130500), payout.Amount)
+	assert.Equal(t, PayoutStatusPending, payout.Status)
+
+	mockRepo.On("GetPayout", mock.Anything, st.FestivalID, payout.ID).Return(payout, nil)
+	mockRepo.On("UpdatePayout", mock.Anything, payout).Return(nil).Once()
+	paid := PayoutStatusPaid
+	_, err = service.UpdatePayout(ctx, st.FestivalID, payout.ID, UpdatePayoutRequest{Status: &paid})
+	require.NoError(t, err)
+	require.NotNil(t, payout.PaidAt)
+	assert.Equal(t, testNow, *payout.PaidAt)
+
+	mockRepo.On("ListPayouts", mock.Anything, st.FestivalID, &st.ID).Return([]Payout{*payout}, nil)
+	statement, err = service.GetStatement(ctx, st.ID, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(130500), statement.Paid)
+	assert.Equal(t, int64(90004), statement.Balance)
+
+	day := time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC)
+	dayStart, dayEnd := cal.Bounds(day)
+	expectSales(mockRepo, st, dayStart, dayEnd, sales[1:])
+	statement, err = service.GetStatement(ctx, st.ID, &day, &day)
+	require.NoError(t, err)
+	require.Len(t, statement.Days, 1)
+	assert.Equal(t, "2026-07-18", statement.Days[0].Date)
+	assert.Zero(t, statement.Paid, "the payout covers another period")
+
+	before := day.AddDate(0, 0, -1)
+	_, err = service.GetStatement(ctx, st.ID, &day, &before)
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+	last := day.AddDate(0, 0, MaxStatementDays)
+	_, err = service.GetStatement(ctx, st.ID, &before, &last)
+	assert.ErrorIs(t, err, ErrPeriodTooLong)
+
+	mockRepo.AssertNumberOfCalls(t, "GetDailySales", 4)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBuildVendorProfitability(t *testing.T) {
+	vendor := BuildVendorProfitability([]StandDailyCosts{
+		{DailySales: DailySales{Orders: 3, GrossSales: 1005, Refunds: 200}, CardSales: 405, CostOfGoods: 300, UncostedSales: 100},
+		{DailySales: DailySales{Orders: 1, GrossSales: 1000}, CardSales: 1000, CostOfGoods: 400},
+	}, 12.5, 1.5)
+	assert.Equal(t, int64(1805), vendor.NetSales)
+	assert.Equal(t, int64(101+125), vendor.Commission)
+	assert.Equal(t, int64(6+15), vendor.Fees) // 6.075 and 15 rounded per day
+	assert.Equal(t, int64(700), vendor.CostOfGoods)
+	assert.Equal(t, int64(100), vendor.UncostedSales)
+	assert.Equal(t, int64(1805-700-226-21), vendor.Profit)
+	assert.Equal(t, 47.5, vendor.MarginPercent)
+
+	assert.Equal(t, VendorProfitability{}, BuildVendorProfitability(nil, 10, 2))
+}
+
+func TestGetProfitability(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+
+	other := *st
+	other.ID = uuid.New()
+	other.Name = "Crêperie"
+	other.CardFeePercent = 2
+	mockRepo.On("ListFestivalStands", mock.Anything, st.FestivalID).Return([]OwnedStand{*st, other}, nil)
+
+	cal := st.Calendar()
+	start, _ := cal.Bounds(st.StartDate)
+	_, end := cal.Bounds(st.EndDate)
+	firstDay := []StandDailyCosts{
+		{StandID: st.ID, DailySales: DailySales{Date: "2026-07-17", Orders: 2, GrossSales: 2000}, CostOfGoods: 800},
+		{StandID: other.ID, DailySales: DailySales{Date: "2026-07-17", Orders: 4, GrossSales: 4000, Refunds: 1000}, CardSales: 3000, CostOfGoods: 500},
+	}
+	lastDay := []StandDailyCosts{
+		{StandID: other.ID, DailySales: DailySales{Date: "2026-07-19", Orders: 1, GrossSales: 1000}, CardSales: 1000, CostOfGoods: 100},
+	}
+	mockRepo.On("GetDailyCosts", mock.Anything, st.FestivalID, start, end, cal).Return(append(firstDay, lastDay...), nil).Once()
+	mockRepo.On("GetRuleSales", mock.Anything, st.FestivalID, (*uuid.UUID)(nil), start, end, cal).Return([]RuleSales{}, nil).Once()
+
+	report, err := service.GetProfitability(ctx, st.FestivalID, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, report.Vendors, 2)
+	assert.Equal(t, other.ID, report.Vendors[0].StandID, "highest profit first")
+	assert.Equal(t, int64(4000-600-400-80), report.Vendors[0].Profit)
+	assert.Equal(t, int64(2000-800-200), report.Vendors[1].Profit)
+	assert.Equal(t, int64(2920+1000), report.Totals.Profit)
+	assert.Equal(t, int64(80), report.Totals.Fees)
+
+	day := time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC)
+	dayStart, dayEnd := cal.Bounds(day)
+	mockRepo.On("GetDailyCosts", mock.Anything, st.FestivalID, dayStart, dayEnd, cal).Return(firstDay, nil).Once()
+	mockRepo.On("GetRuleSales", mock.Anything, st.FestivalID, (*uuid.UUID)(nil), dayStart, dayEnd, cal).Return([]RuleSales{}, nil).Once()
+	report, err = service.GetProfitability(ctx, st.FestivalID, &day, &day)
+	require.NoError(t, err)
+	assert.Equal(t, dayStart, report.PeriodStart)
+	assert.Equal(t, int64(3000-500-300-60), report.Vendors[0].Profit)
+
+	// A festival without stands has nothing to report
+	empty := uuid.New()
+	mockRepo.On("ListFestivalStands", mock.Anything, empty).Return([]OwnedStand{}, nil)
+	report, err = service.GetProfitability(ctx, empty, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Vendors)
+
+	mockRepo.AssertNumberOfCalls(t, "GetDailyCosts", 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetPayouts(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+
+	// Earned to date, from the first sale on
+	expectSales(mockRepo, st, time.Time{}, testNow, testSales())
+	mockRepo.On("ListAdjustments", mock.Anything, st.FestivalID, &st.ID).Return([]SettlementAdjustment{}, nil)
+	mockRepo.On("ListPayouts", mock.Anything, st.FestivalID, &st.ID).Return([]Payout{
+		{ID: uuid.New(), FestivalID: st.FestivalID, StandID: st.ID, Amount: 100000, Status: PayoutStatusPaid},
+		{ID: uuid.New(), FestivalID: st.FestivalID, StandID: st.ID, Amount: 50000, Status: PayoutStatusPending},
+		{ID: uuid.New(), FestivalID: st.FestivalID, StandID: st.ID, Amount: 20000, Status: PayoutStatusFailed},
+	}, nil)
+
+	summary, err := service.GetPayouts(ctx, st.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(220504), summary.Earned)
+	assert.Equal(t, int64(100000), summary.Paid)
+	assert.Equal(t, int64(50000), summary.Pending)
+	assert.Equal(t, int64(70504), summary.Outstanding)
+	assert.Len(t, summary.Payouts, 3)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAssignStaff(t *testing.T) {
+	service, _ := newTestService(NewMockRepository())
+	st := testStand()
+	ctx := context.Background()
+	cashier := uuid.New()
+
+	_, err := service.AssignStaff(ctx, st.ID, stand.AssignStaffRequest{UserID: cashier, Role: "OWNER"})
+	assert.ErrorIs(t, err, ErrInvalidStaffRole)
+
+	member, err := service.AssignStaff(ctx, st.ID, stand.AssignStaffRequest{UserID: cashier, Role: stand.StaffRoleCashier})
+	require.NoError(t, err)
+	assert.Equal(t, st.ID, member.StandID)
+
+	_, err = service.AssignStaff(ctx, st.ID, stand.AssignStaffRequest{UserID: cashier, Role: stand.StaffRoleManager})
+	assert.ErrorIs(t, err, ErrAlreadyStaff)
+
+	require.NoError(t, service.RemoveStaff(ctx, st.ID, cashier))
+	assert.ErrorIs(t, service.RemoveStaff(ctx, st.ID, cashier), ErrStaffNotFound)
+}
+
+func TestBuildStatementDay_CommissionRules(t *testing.T) {
+	ruleID := uuid.New()
+	day := BuildStatementDay(DailySales{
+		Date:       "2026-07-17",
+		Orders:     10,
+		GrossSales: 10000,
+		Refunds:    1000,
+		Rules: []RuleSales{
+			{RuleID: ruleID, Label: "Opening night", Percent: 0, Orders: 4, GrossSales: 4000, Refunds: 500},
+		},
+	}, 10)
+	assert.Equal(t, int64(9000), day.NetSales)
+	assert.Equal(t, int64(550), day.Commission, "10% of the 5500 outside the fee holiday")
+	assert.Equal(t, int64(8450), day.Payable)
+	require.Len(t, day.Rules, 1)
+	assert.Equal(t, ruleID, day.Rules[0].RuleID)
+	assert.Equal(t, int64(3500), day.Rules[0].NetSales)
+	assert.Equal(t, int64(0), day.Rules[0].Commission)
+}
+
+func TestGetStatement_RulesAndAdjustments(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+	mockRepo.On("ListPayouts", mock.Anything, st.FestivalID, &st.ID).Return([]Payout{}, nil)
+
+	ruleSales := []RuleSales{
+		{StandID: st.ID, Date: "2026-07-17", RuleID: uuid.New(), Label: "Opening night", Orders: 20, GrossSales: 50000},
+	}
+	cal := st.Calendar()
+	start, _ := cal.Bounds(st.StartDate)
+	_, end := cal.Bounds(st.EndDate)
+	expectSales(mockRepo, st, start, end, testSales(), ruleSales...)
+	expectSales(mockRepo, st, time.Time{}, testNow, testSales(), ruleSales...)
+	mockRepo.On("ListAdjustments", mock.Anything, st.FestivalID, &st.ID).Return([]SettlementAdjustment{
+		{FestivalID: st.FestivalID, StandID: st.ID, Date: time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC), Amount: 1500, Reason: "Broken freezer"},
+		{FestivalID: st.FestivalID, StandID: st.ID, Date: time.Date(2026, 7, 25, 0, 0, 0, 0, time.UTC), Amount: 99, Reason: "Outside the period"},
+	}, nil)
+
+	statement, err := service.GetStatement(ctx, st.ID, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9500+10001), statement.Totals.Commission, "no commission on the 50000 of opening night")
+	require.Len(t, statement.Days[0].Rules, 1)
+	require.Len(t, statement.Adjustments, 1)
+	assert.Equal(t, int64(1500), statement.Totals.Adjustments)
+	assert.Equal(t, statement.Totals.Payable+1500, statement.Balance)
+
+	summary, err := service.GetPayouts(ctx, st.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statement.Totals.Payable+1500+99, summary.Earned)
+}
+
+func TestCreateCommissionRule(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+
+	other := *st
+	other.ID = uuid.New()
+	other.CommissionPercent = 20
+	mockRepo.On("ListFestivalStands", mock.Anything, st.FestivalID).Return([]OwnedStand{*st, other}, nil)
+
+	cal := st.Calendar()
+	start, end := cal.Bounds(time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC))
+	mockRepo.On("GetDailyCosts", mock.Anything, st.FestivalID, start, end, cal).Return([]StandDailyCosts{
+		{StandID: st.ID, DailySales: DailySales{Date: "2026-07-17", Orders: 10, GrossSales: 10000, Refunds: 1000}},
+		{StandID: other.ID, DailySales: DailySales{Date: "2026-07-17", Orders: 5, GrossSales: 5000}},
+	}, nil).Once()
+	// The other stand already had a 5% rule for part of the night
+	mockRepo.On("GetRuleSales", mock.Anything, st.FestivalID, (*uuid.UUID)(nil), start, end, cal).Return([]RuleSales{
+		{StandID: other.ID, Date: "2026-07-17", RuleID: uuid.New(), Percent: 5, StandRule: true, Orders: 2, GrossSales: 2000},
+	}, nil).Once()
+
+	mockRepo.On("ListCommissionRules", mock.Anything, st.FestivalID, (*uuid.UUID)(nil)).Return([]CommissionRule{}, nil).Once()
+	mockRepo.On("CreateCommissionRule", mock.Anything, mock.AnythingOfType("*vendorportal.CommissionRule"),
+		mock.MatchedBy(func(adjustments []SettlementAdjustment) bool { return len(adjustments) == 2 })).Return(nil).Once()
+	percent := 0.0
+	created, err := service.CreateCommissionRule(ctx, st.FestivalID, CreateCommissionRuleRequest{
+		Label:    "Opening night",
+		Percent:  &percent,
+		StartsAt: start,
+		EndsAt:   end,
+	}, nil)
+	require.NoError(t, err)
+
+	// The fee holiday started before the rule was created: the commission charged on
+	// opening night is owed back rather than taken off the statement days
+	require.Len(t, created.Adjustments, 2)
+	amounts := map[uuid.UUID]int64{}
+	for _, adjustment := range created.Adjustments {
+		assert.Equal(t, &created.Rule.ID, adjustment.RuleID)
+		assert.Equal(t, time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC), adjustment.Date)
+		amounts[adjustment.StandID] = adjustment.Amount
+	}
+	assert.Equal(t, int64(900), amounts[st.ID])
+	assert.Equal(t, int64(600), amounts[other.ID], "the stand rule keeps its 5% on 2000")
+
+	mockRepo.On("ListCommissionRules", mock.Anything, st.FestivalID, (*uuid.UUID)(nil)).Return([]CommissionRule{created.Rule}, nil).Once()
+	_, err = service.CreateCommissionRule(ctx, st.FestivalID, CreateCommissionRuleRequest{
+		Label:    "Overlapping",
+		Percent:  &percent,
+		StartsAt: start.Add(time.Hour),
+		EndsAt:   end.Add(time.Hour),
+	}, nil)
+	assert.ErrorIs(t, err, ErrCommissionRuleOverlap)
+
+	_, err = service.CreateCommissionRule(ctx, st.FestivalID, CreateCommissionRuleRequest{
+		Label:    "Backwards",
+		Percent:  &percent,
+		StartsAt: end,
+		EndsAt:   start,
+	}, nil)
+	assert.ErrorIs(t, err, ErrInvalidRuleWindow)
+
+	mockRepo.On("GetCommissionRule", mock.Anything, st.FestivalID, created.Rule.ID).Return(&created.Rule, nil)
+	assert.ErrorIs(t, service.DeleteCommissionRule(ctx, st.FestivalID, created.Rule.ID), ErrCommissionRuleStarted)
+
+	// A stand rule may sit within a festival-wide one, and goes while it has not started
+	mockRepo.On("ListCommissionRules", mock.Anything, st.FestivalID, &st.ID).Return([]CommissionRule{created.Rule}, nil).Once()
+	mockRepo.On("CreateCommissionRule", mock.Anything, mock.AnythingOfType("*vendorportal.CommissionRule"), []SettlementAdjustment{}).Return(nil).Once()
+	future, err := service.CreateCommissionRule(ctx, st.FestivalID, CreateCommissionRuleRequest{
+		StandID:  &st.ID,
+		Label:    "Closing party",
+		Percent:  &percent,
+		StartsAt: end.Add(24 * time.Hour),
+		EndsAt:   end.Add(30 * time.Hour),
+	}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, future.Adjustments)
+
+	mockRepo.On("GetCommissionRule", mock.Anything, st.FestivalID, future.Rule.ID).Return(&future.Rule, nil)
+	mockRepo.On("DeleteCommissionRule", mock.Anything, future.Rule.ID).Return(nil).Once()
+	require.NoError(t, service.DeleteCommissionRule(ctx, st.FestivalID, future.Rule.ID))
+
+	mockRepo.AssertNumberOfCalls(t, "CreateCommissionRule", 2)
+	mockRepo.AssertNumberOfCalls(t, "DeleteCommissionRule", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAdjustment(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service, _ := newTestService(mockRepo)
+	ctx := context.Background()
+	st := testStand()
+	mockRepo.On("GetStand", mock.Anything, st.ID).Return(st, nil)
+
+	mockRepo.On("CreateAdjustment", mock.Anything, mock.MatchedBy(func(a *SettlementAdjustment) bool {
+		return a.FestivalID == st.FestivalID && a.StandID == st.ID && a.Amount == -2500
+	})).Return(nil).Once()
+	adjustment, err := service.CreateAdjustment(ctx, st.FestivalID, CreateAdjustmentRequest{
+		StandID: st.ID,
+		Date:    "2026-07-18",
+		Amount:  -2500,
+		Reason:  "Festival tokens accepted by mistake",
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC), adjustment.Date)
+
+	_, err = service.CreateAdjustment(ctx, st.FestivalID, CreateAdjustmentRequest{StandID: st.ID, Date: "18/07/2026", Amount: 1, Reason: "x"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAdjustmentDate)
+	_, err = service.CreateAdjustment(ctx, uuid.New(), CreateAdjustmentRequest{StandID: st.ID, Date: "2026-07-18", Amount: 1, Reason: "x"}, nil)
+	assert.ErrorIs(t, err, ErrStandNotFound)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateAdjustment", 1)
+}
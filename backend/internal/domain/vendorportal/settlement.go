@@ -0,0 +1,246 @@
+package vendorportal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Commission rules and settlement adjustments, managed by organizers
+
+func (s *Service) ListCommissionRules(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]CommissionRule, error) {
+	return s.repo.ListCommissionRules(ctx, festivalID, standID)
+}
+
+// CreateCommissionRule creates a commission rule for a stand, or for every stand of the
+// festival. A rule starting in the past does not change the statement days already
+// shown: the orders it covers from before its creation are corrected with an adjustment
+// per stand and day, returned with the rule.
+func (s *Service) CreateCommissionRule(ctx context.Context, festivalID uuid.UUID, req CreateCommissionRuleRequest, createdBy *uuid.UUID) (*CreateCommissionRuleResponse, error) {
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, ErrInvalidRuleWindow
+	}
+	if req.StandID != nil {
+		if _, err := s.festivalStand(ctx, festivalID, *req.StandID); err != nil {
+			return nil, err
+		}
+	}
+
+	rule := &CommissionRule{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    req.StandID,
+		Label:      req.Label,
+		Percent:    *req.Percent,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		CreatedBy:  createdBy,
+		CreatedAt:  s.now(),
+	}
+
+	rules, err := s.repo.ListCommissionRules(ctx, festivalID, req.StandID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rule.Overlaps(&rules[i]) {
+			return nil, ErrCommissionRuleOverlap
+		}
+	}
+
+	adjustments := []SettlementAdjustment{}
+	if rule.StartsAt.Before(rule.CreatedAt) {
+		adjustments, err = s.retroactiveAdjustments(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.CreateCommissionRule(ctx, rule, adjustments); err != nil {
+		return nil, err
+	}
+	return &CreateCommissionRuleResponse{Rule: *rule, Adjustments: adjustments}, nil
+}
+
+// DeleteCommissionRule deletes a rule that has not started yet. A started rule is part
+// of the statements; what it got wrong is corrected with adjustments.
+func (s *Service) DeleteCommissionRule(ctx context.Context, festivalID, id uuid.UUID) error {
+	rule, err := s.repo.GetCommissionRule(ctx, festivalID, id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrCommissionRuleNotFound
+	}
+	if !rule.StartsAt.After(s.now()) {
+		return ErrCommissionRuleStarted
+	}
+	return s.repo.DeleteCommissionRule(ctx, id)
+}
+
+func (s *Service) ListAdjustments(ctx context.Context, festivalID uuid.UUID, standID *uuid.UUID) ([]SettlementAdjustment, error) {
+	return s.repo.ListAdjustments(ctx, festivalID, standID)
+}
+
+// CreateAdjustment corrects what a stand is owed for one of its operational days. The
+// adjustment shows on the statements covering the day.
+func (s *Service) CreateAdjustment(ctx context.Context, festivalID uuid.UUID, req CreateAdjustmentRequest, createdBy *uuid.UUID) (*SettlementAdjustment, error) {
+	if _, err := s.festivalStand(ctx, festivalID, req.StandID); err != nil {
+		return nil, err
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, ErrInvalidAdjustmentDate
+	}
+
+	adjustment := &SettlementAdjustment{
+		ID:         uuid.New(),
+		FestivalID: festivalID,
+		StandID:    req.StandID,
+		Date:       date,
+		Amount:     req.Amount,
+		Reason:     req.Reason,
+		CreatedBy:  createdBy,
+		CreatedAt:  s.now(),
+	}
+	if err := s.repo.CreateAdjustment(ctx, adjustment); err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// retroactiveAdjustments returns the adjustments correcting the commission of the
+// orders a new rule covers from before its creation: per stand and day, the commission
+// they were charged minus the commission under the rule.
+func (s *Service) retroactiveAdjustments(ctx context.Context, rule *CommissionRule) ([]SettlementAdjustment, error) {
+	stands, err := s.repo.ListFestivalStands(ctx, rule.FestivalID)
+	if err != nil {
+		return nil, err
+	}
+	adjustments := []SettlementAdjustment{}
+	if len(stands) == 0 {
+		return adjustments, nil
+	}
+	percents := make(map[uuid.UUID]float64, len(stands))
+	for _, st := range stands {
+		percents[st.ID] = st.CommissionPercent
+	}
+
+	end := rule.EndsAt
+	if rule.CreatedAt.Before(end) {
+		end = rule.CreatedAt
+	}
+	cal := stands[0].Calendar()
+	days, err := s.repo.GetDailyCosts(ctx, rule.FestivalID, rule.StartsAt, end, cal)
+	if err != nil {
+		return nil, err
+	}
+	ruleSales, err := s.repo.GetRuleSales(ctx, rule.FestivalID, rule.StandID, rule.StartsAt, end, cal)
+	if err != nil {
+		return nil, err
+	}
+	attachRuleSales(days, ruleSales)
+
+	for _, day := range days {
+		percent, ok := percents[day.StandID]
+		if !ok || (rule.StandID != nil && day.StandID != *rule.StandID) {
+			continue
+		}
+		charged := BuildStatementDay(day.DailySales, percent).Commission
+		revised := BuildStatementDay(applyRule(day.DailySales, rule), percent).Commission
+		if charged == revised {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sales date: %w", err)
+		}
+		adjustments = append(adjustments, SettlementAdjustment{
+			ID:         uuid.New(),
+			FestivalID: rule.FestivalID,
+			StandID:    day.StandID,
+			Date:       date,
+			Amount:     charged - revised,
+			Reason:     fmt.Sprintf("Commission rule %q applied after the fact", rule.Label),
+			RuleID:     &rule.ID,
+			CreatedBy:  rule.CreatedBy,
+			CreatedAt:  rule.CreatedAt,
+		})
+	}
+	return adjustments, nil
+}
+
+// applyRule returns a day of sales, all within the window of a new rule, as if the rule
+// had covered them: all of them for a stand rule, those not under a stand rule for a
+// festival-wide one
+func applyRule(sales DailySales, rule *CommissionRule) DailySales {
+	covered := RuleSales{
+		Date:       sales.Date,
+		RuleID:     rule.ID,
+		Label:      rule.Label,
+		Percent:    rule.Percent,
+		StandRule:  rule.StandID != nil,
+		Orders:     sales.Orders,
+		GrossSales: sales.GrossSales,
+		Refunds:    sales.Refunds,
+	}
+
+	revised := sales
+	revised.Rules = nil
+	if rule.StandID == nil {
+		for _, kept := range sales.Rules {
+			if kept.StandRule {
+				revised.Rules = append(revised.Rules, kept)
+				covered.Orders -= kept.Orders
+				covered.GrossSales -= kept.GrossSales
+				covered.Refunds -= kept.Refunds
+			}
+		}
+	}
+	revised.Rules = append(revised.Rules, covered)
+	return revised
+}
+
+// dailySales returns the daily sales of a stand with their part under commission rules
+func (s *Service) dailySales(ctx context.Context, st *OwnedStand, start, end time.Time) ([]DailySales, error) {
+	cal := st.Calendar()
+	days, err := s.repo.GetDailySales(ctx, st.ID, start, end, cal)
+	if err != nil {
+		return nil, err
+	}
+	ruleSales, err := s.repo.GetRuleSales(ctx, st.FestivalID, &st.ID, start, end, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]int, len(days))
+	for i, day := range days {
+		byDate[day.Date] = i
+	}
+	for _, sales := range ruleSales {
+		if i, ok := byDate[sales.Date]; ok {
+			days[i].Rules = append(days[i].Rules, sales)
+		}
+	}
+	return days, nil
+}
+
+// attachRuleSales adds the sales under commission rules to the daily sales of the stands
+func attachRuleSales(days []StandDailyCosts, ruleSales []RuleSales) {
+	type standDay struct {
+		standID uuid.UUID
+		date    string
+	}
+	index := make(map[standDay]int, len(days))
+	for i, day := range days {
+		index[standDay{day.StandID, day.Date}] = i
+	}
+	for _, sales := range ruleSales {
+		if i, ok := index[standDay{sales.StandID, sales.Date}]; ok {
+			days[i].Rules = append(days[i].Rules, sales)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+)
+
+// activityMaxTransactions bounds the history grouped into activity items
+const activityMaxTransactions = 2000
+
+// ActivityStatus is the outcome of an activity item over all its entries
+type ActivityStatus string
+
+const (
+	ActivityStatusCompleted         ActivityStatus = "COMPLETED"
+	ActivityStatusPartiallyRefunded ActivityStatus = "PARTIALLY_REFUNDED"
+	ActivityStatusRefunded          ActivityStatus = "REFUNDED"
+	ActivityStatusPending           ActivityStatus = "PENDING"
+	ActivityStatusFailed            ActivityStatus = "FAILED"
+)
+
+// ActivityRequest selects the period and page of a wallet's activity and how it is presented
+type ActivityRequest struct {
+	From         *time.Time // Start of the wallet history when nil
+	To           *time.Time // Now when nil
+	Page         int
+	PerPage      int
+	Locale       string
+	ExchangeRate float64
+	CurrencyName string
+}
+
+// ActivityItem is an entry of the history screen of the attendee app: a transaction
+// with the transactions that amended it, e.g. a purchase and its refunds
+type ActivityItem struct {
+	ID            uuid.UUID         `json:"id"` // Transaction that opened the item
+	Type          TransactionType   `json:"type"`
+	Status        ActivityStatus    `json:"status"`
+	Title         string            `json:"title"`                 // e.g. "Purchase at Main Bar"
+	Description   string            `json:"description,omitempty"` // e.g. "2 × Beer, Fries"
+	StandID       *uuid.UUID        `json:"standId,omitempty"`
+	StandName     string            `json:"standName,omitempty"`
+	Products      []ActivityProduct `json:"products,omitempty"`
+	Amount        int64             `json:"amount"` // Net amount of the entries, in cents
+	AmountDisplay string            `json:"amountDisplay"`
+	BalanceAfter  int64             `json:"balanceAfter"`
+	OccurredAt    time.Time         `json:"occurredAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"` // Time of the last entry
+	Entries       []ActivityEntry   `json:"entries"`
+}
+
+// ActivityProduct is a product bought in an activity item
+type ActivityProduct struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Quantity int       `json:"quantity"`
+}
+
+// ActivityEntry is a transaction of an activity item
+type ActivityEntry struct {
+	TransactionID uuid.UUID         `json:"transactionId"`
+	Type          TransactionType   `json:"type"`
+	Status        TransactionStatus `json:"status"`
+	Description   string            `json:"description"`
+	Amount        int64             `json:"amount"`
+	AmountDisplay string            `json:"amountDisplay"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
+
+// ActivityNames holds the names of the stands and products referenced by transactions
+type ActivityNames struct {
+	Stands   map[uuid.UUID]string
+	Products map[uuid.UUID]string
+}
+
+// GetActivity returns the activity of a user's wallet in a festival over a period,
+// most recently updated first, with the total number of items
+func (s *Service) GetActivity(ctx context.Context, userID, festivalID uuid.UUID, req ActivityRequest) ([]ActivityItem, int, error) {
+	wallet, err := s.repo.GetWalletByUserAndFestival(ctx, userID, festivalID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if wallet == nil {
+		return nil, 0, errors.ErrNotFound
+	}
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := wallet.CreatedAt
+	if req.From != nil {
+		from = *req.From
+	}
+	if from.After(to) {
+		return nil, 0, ErrActivityPeriod
+	}
+
+	transactions, _, err := s.repo.GetTransactionsByWalletWithDateRange(ctx, wallet.ID, from, to, 0, activityMaxTransactions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	names, err := s.activityNames(ctx, transactions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := BuildActivity(transactions, names, req.Locale, req.ExchangeRate, req.CurrencyName)
+	total := len(items)
+
+	page, perPage := req.Page, req.PerPage
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	start := (page - 1) * perPage
+	if start >= total {
+		return []ActivityItem{}, total, nil
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	return items[start:end], total, nil
+}
+
+// activityNames looks up the names of the stands and products of transactions
+func (s *Service) activityNames(ctx context.Context, transactions []Transaction) (ActivityNames, error) {
+	standIDs := make(map[uuid.UUID]bool)
+	productIDs := make(map[uuid.UUID]bool)
+	for _, tx := range transactions {
+		if tx.StandID != nil {
+			standIDs[*tx.StandID] = true
+		}
+		for _, raw := range tx.Metadata.ProductIDs {
+			if id, err := uuid.Parse(raw); err == nil {
+				productIDs[id] = true
+			}
+		}
+	}
+
+	names := ActivityNames{Stands: map[uuid.UUID]string{}, Products: map[uuid.UUID]string{}}
+	var err error
+	if len(standIDs) > 0 {
+		if names.Stands, err = s.repo.GetStandNames(ctx, keys(standIDs)); err != nil {
+			return names, fmt.Errorf("failed to get stand names: %w", err)
+		}
+	}
+	if len(productIDs) > 0 {
+		if names.Products, err = s.repo.GetProductNames(ctx, keys(productIDs)); err != nil {
+			return names, fmt.Errorf("failed to get product names: %w", err)
+		}
+	}
+	return names, nil
+}
+
+// BuildActivity groups transactions into activity items. Refunds and replacement
+// purchases reference the transaction they amend, and join its item; an amended
+// transaction outside of the transactions gives its refund an item of its own.
+func BuildActivity(transactions []Transaction, names ActivityNames, locale string, exchangeRate float64, currencyName string) []ActivityItem {
+	ordered := make([]Transaction, len(transactions))
+	copy(ordered, transactions)
+	// A correction books the refund of the original purchase and its replacement at once
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].Type == TransactionTypeRefund && ordered[j].Type != TransactionTypeRefund
+		}
+		return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+	})
+
+	var groups [][]Transaction
+	groupOf := make(map[uuid.UUID]int, len(ordered))
+	for _, tx := range ordered {
+		if original, err := uuid.Parse(tx.Reference); err == nil && amends(tx) {
+			if g, ok := groupOf[original]; ok {
+				groups[g] = append(groups[g], tx)
+				groupOf[tx.ID] = g
+				continue
+			}
+		}
+		groupOf[tx.ID] = len(groups)
+		groups = append(groups, []Transaction{tx})
+	}
+
+	items := make([]ActivityItem, 0, len(groups))
+	for _, group := range groups {
+		items = append(items, buildActivityItem(group, names, locale, exchangeRate, currencyName))
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].UpdatedAt.After(items[j].UpdatedAt) })
+	return items
+}
+
+// amends reports whether a transaction amends the one of its reference: refunds and
+// the purchases replacing a corrected order
+func amends(tx Transaction) bool {
+	return tx.Type == TransactionTypeRefund || tx.Type == TransactionTypePurchase
+}
+
+func buildActivityItem(group []Transaction, names ActivityNames, locale string, exchangeRate float64, currencyName string) ActivityItem {
+	first, last := group[0], group[len(group)-1]
+	display := func(cents int64) string {
+		return formatTokens(float64(cents)*exchangeRate, currencyName)
+	}
+
+	item := ActivityItem{
+		ID:           first.ID,
+		Type:         first.Type,
+		StandID:      first.StandID,
+		BalanceAfter: last.BalanceAfter,
+		OccurredAt:   first.CreatedAt,
+		UpdatedAt:    last.CreatedAt,
+		Entries:      make([]ActivityEntry, 0, len(group)),
+	}
+	if item.StandID != nil {
+		item.StandName = names.Stands[*item.StandID]
+	}
+
+	refunded := false
+	var purchase *Transaction
+	for i, tx := range group {
+		item.Entries = append(item.Entries, ActivityEntry{
+			TransactionID: tx.ID,
+			Type:          tx.Type,
+			Status:        tx.Status,
+			Description:   entryDescription(tx, locale),
+			Amount:        tx.Amount,
+			AmountDisplay: display(tx.Amount),
+			CreatedAt:     tx.CreatedAt,
+		})
+		if tx.Status == TransactionStatusFailed {
+			continue
+		}
+		item.Amount += tx.Amount
+		if tx.Type == TransactionTypeRefund && i > 0 {
+			refunded = true
+		}
+		if tx.Type == TransactionTypePurchase {
+			purchase = &group[i]
+		}
+	}
+	item.AmountDisplay = display(item.Amount)
+
+	// The products are the ones of the last purchase, which replaces corrected orders
+	if purchase != nil {
+		item.Products = activityProducts(purchase.Metadata.ProductIDs, names, locale)
+	}
+
+	switch {
+	case first.Status == TransactionStatusFailed:
+		item.Status = ActivityStatusFailed
+	case first.Status == TransactionStatusPending:
+		item.Status = ActivityStatusPending
+	case refunded && item.Amount == 0:
+		item.Status = ActivityStatusRefunded
+	case refunded:
+		item.Status = ActivityStatusPartiallyRefunded
+	default:
+		item.Status = ActivityStatusCompleted
+	}
+
+	item.Title = i18n.T(locale, "activity.title."+string(item.Type))
+	if item.StandName != "" {
+		item.Title = i18n.T(locale, "activity.title_at."+string(item.Type), i18n.Params{"stand": item.StandName})
+	}
+	if len(item.Products) > 0 {
+		parts := make([]string, len(item.Products))
+		for i, p := range item.Products {
+			parts[i] = p.Name
+			if p.Quantity > 1 {
+				parts[i] = i18n.T(locale, "activity.product_quantity", i18n.Params{"quantity": p.Quantity, "name": p.Name})
+			}
+		}
+		item.Description = strings.Join(parts, ", ")
+	} else {
+		item.Description = first.Metadata.Description
+	}
+
+	return item
+}
+
+// activityProducts counts the products of a purchase, in the order they were bought
+func activityProducts(productIDs []string, names ActivityNames, locale string) []ActivityProduct {
+	var products []ActivityProduct
+	index := make(map[uuid.UUID]int)
+	for _, raw := range productIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if i, ok := index[id]; ok {
+			products[i].Quantity++
+			continue
+		}
+		name, ok := names.Products[id]
+		if !ok {
+			name = i18n.T(locale, "activity.unknown_product")
+		}
+		index[id] = len(products)
+		products = append(products, ActivityProduct{ID: id, Name: name, Quantity: 1})
+	}
+	return products
+}
+
+// entryDescription describes a transaction of an item, with its reason when it has one
+func entryDescription(tx Transaction, locale string) string {
+	description := i18n.T(locale, "activity.entry."+string(tx.Type))
+	if tx.Type == TransactionTypePurchase && tx.Reference != "" {
+		description = i18n.T(locale, "activity.entry.REPLACEMENT")
+	}
+	if tx.Metadata.Description != "" {
+		description = i18n.T(locale, "activity.entry_reason", i18n.Params{"entry": description, "reason": tx.Metadata.Description})
+	}
+	return description
+}
+
+func keys(set map[uuid.UUID]bool) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildActivity(t *testing.T) {
+	walletID := uuid.New()
+	standID := uuid.New()
+	beer, fries := uuid.New(), uuid.New()
+	day := time.Date(2026, 7, 12, 18, 0, 0, 0, time.UTC)
+	names := ActivityNames{
+		Stands:   map[uuid.UUID]string{standID: "Main Bar"},
+		Products: map[uuid.UUID]string{beer: "Beer", fries: "Fries"},
+	}
+
+	topUp := statementTransaction(walletID, TransactionTypeTopUp, 5000, 5000, day)
+	purchase := statementTransaction(walletID, TransactionTypePurchase, -1200, 3800, day.Add(time.Hour))
+	purchase.StandID = &standID
+	purchase.Metadata.ProductIDs = []string{beer.String(), beer.String(), fries.String()}
+	refund := statementTransaction(walletID, TransactionTypeRefund, 400, 4200, day.Add(3*time.Hour))
+	refund.Reference = purchase.ID.String()
+	refund.Metadata.Description = "Cold fries"
+
+	second := statementTransaction(walletID, TransactionTypePurchase, -500, 3700, day.Add(2*time.Hour))
+	second.StandID = &standID
+	second.Metadata.ProductIDs = []string{beer.String()}
+	fullRefund := statementTransaction(walletID, TransactionTypeRefund, 500, 4200, day.Add(2*time.Hour+time.Minute))
+	fullRefund.Reference = second.ID.String()
+
+	// Newest first, as returned by the repository
+	transactions := []Transaction{refund, fullRefund, second, purchase, topUp}
+
+	items := BuildActivity(transactions, names, "en", 1, "EUR")
+	require.Len(t, items, 3)
+
+	// Ordered by last update: the partially refunded purchase was refunded last
+	first := items[0]
+	assert.Equal(t, purchase.ID, first.ID)
+	assert.Equal(t, "Purchase at Main Bar", first.Title)
+	assert.Equal(t, "2 × Beer, Fries", first.Description)
+	assert.Equal(t, ActivityStatusPartiallyRefunded, first.Status)
+	assert.Equal(t, int64(-800), first.Amount)
+	assert.Equal(t, int64(4200), first.BalanceAfter)
+	assert.Equal(t, purchase.CreatedAt, first.OccurredAt)
+	assert.Equal(t, refund.CreatedAt, first.UpdatedAt)
+	require.Len(t, first.Entries, 2)
+	assert.Equal(t, "Refund: Cold fries", first.Entries[1].Description)
+	require.Len(t, first.Products, 2)
+	assert.Equal(t, 2, first.Products[0].Quantity)
+
+	assert.Equal(t, second.ID, items[1].ID)
+	assert.Equal(t, ActivityStatusRefunded, items[1].Status)
+	assert.Equal(t, int64(0), items[1].Amount)
+
+	assert.Equal(t, topUp.ID, items[2].ID)
+	assert.Equal(t, "Top-up", items[2].Title)
+	assert.Equal(t, ActivityStatusCompleted, items[2].Status)
+	assert.Len(t, items[2].Entries, 1)
+}
+
+func TestBuildActivity_Replacement(t *testing.T) {
+	walletID := uuid.New()
+	standID := uuid.New()
+	beer, wine := uuid.New(), uuid.New()
+	day := time.Date(2026, 7, 12, 18, 0, 0, 0, time.UTC)
+	names := ActivityNames{
+		Stands:   map[uuid.UUID]string{standID: "Bar du Parc"},
+		Products: map[uuid.UUID]string{beer: "Bière"},
+	}
+
+	original := statementTransaction(walletID, TransactionTypePurchase, -1000, 2000, day)
+	original.StandID = &standID
+	original.Metadata.ProductIDs = []string{beer.String()}
+	reversal := statementTransaction(walletID, TransactionTypeRefund, 1000, 3000, day.Add(time.Minute))
+	reversal.Reference = original.ID.String()
+	replacement := statementTransaction(walletID, TransactionTypePurchase, -800, 2200, day.Add(time.Minute))
+	replacement.StandID = &standID
+	replacement.Reference = original.ID.String()
+	replacement.Metadata.ProductIDs = []string{wine.String()}
+
+	items := BuildActivity([]Transaction{replacement, reversal, original}, names, "fr", 1, "EUR")
+	require.Len(t, items, 1)
+
+	item := items[0]
+	assert.Equal(t, "Achat à Bar du Parc", item.Title)
+	assert.Equal(t, int64(-800), item.Amount)
+	require.Len(t, item.Entries, 3)
+	assert.Equal(t, "Paiement corrigé", item.Entries[2].Description)
+	// The products are the ones of the replacement, whose name is unknown
+	require.Len(t, item.Products, 1)
+	assert.Equal(t, wine, item.Products[0].ID)
+	assert.Equal(t, "Article", item.Description)
+}
+
+func TestBuildActivity_RefundOfEarlierPurchase(t *testing.T) {
+	walletID := uuid.New()
+	refund := statementTransaction(walletID, TransactionTypeRefund, 300, 1300, time.Now())
+	refund.Reference = uuid.NewString()
+
+	items := BuildActivity([]Transaction{refund}, ActivityNames{}, "en", 1, "EUR")
+	require.Len(t, items, 1)
+	assert.Equal(t, "Refund", items[0].Title)
+	assert.Equal(t, ActivityStatusCompleted, items[0].Status)
+}
+
+func TestService_GetActivity(t *testing.T) {
+	userID := uuid.New()
+	festivalID := uuid.New()
+	standID := uuid.New()
+	wallet := &Wallet{ID: uuid.New(), UserID: &userID, FestivalID: festivalID, CreatedAt: time.Now().Add(-48 * time.Hour)}
+
+	var transactions []Transaction
+	for i := 0; i < 3; i++ {
+		tx := statementTransaction(wallet.ID, TransactionTypePurchase, -100, 1000, time.Now().Add(-time.Duration(i)*time.Hour))
+		tx.StandID = &standID
+		transactions = append(transactions, tx)
+	}
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(wallet, nil)
+	mockRepo.On("GetTransactionsByWalletWithDateRange", mock.Anything, wallet.ID, wallet.CreatedAt, mock.Anything, 0, activityMaxTransactions).
+		Return(transactions, int64(len(transactions)), nil)
+	mockRepo.On("GetStandNames", mock.Anything, []uuid.UUID{standID}).Return(map[uuid.UUID]string{standID: "Food Court"}, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+	items, total, err := service.GetActivity(context.Background(), userID, festivalID, ActivityRequest{
+		Page: 2, PerPage: 2, Locale: "en", ExchangeRate: 0.1, CurrencyName: "Tokens",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, total)
+	require.Len(t, items, 1)
+	assert.Equal(t, transactions[2].ID, items[0].ID)
+	assert.Equal(t, "Purchase at Food Court", items[0].Title)
+	assert.Equal(t, "-10 Tokens", items[0].AmountDisplay)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetActivity_InvalidPeriod(t *testing.T) {
+	userID := uuid.New()
+	festivalID := uuid.New()
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(&Wallet{ID: uuid.New()}, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	_, _, err := service.GetActivity(context.Background(), userID, festivalID, ActivityRequest{From: &from, To: &to})
+	assert.ErrorIs(t, err, ErrActivityPeriod)
+}
@@ -0,0 +1,320 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeSendActivityDigests is the worker task sending the activity digests due
+const TypeSendActivityDigests = "wallet:send_activity_digests"
+
+// ActivityDigestHour is the hour of the festival local time the nightly digests are
+// sent at, each summarizing the 24 hours before
+const ActivityDigestHour = 22
+
+// ActivityDigestTopStands is the number of stands a digest names
+const ActivityDigestTopStands = 3
+
+// ActivityNotifier pushes the activity of wallets to their holders: a notification per
+// transaction in instant mode, a nightly digest in digest mode; satisfied by
+// jobs.PushQueue
+type ActivityNotifier interface {
+	NotifyWalletActivity(ctx context.Context, notice ActivityNotice) error
+	NotifyActivityDigest(ctx context.Context, digest ActivityDigest) error
+}
+
+// ActivityNotice tells the holder of a wallet in instant mode about a purchase or top-up
+type ActivityNotice struct {
+	UserID        uuid.UUID
+	Locale        string
+	FestivalID    uuid.UUID
+	FestivalName  string
+	CurrencyName  string
+	TransactionID uuid.UUID
+	Type          TransactionType
+	Amount        int64  // In cents, positive
+	StandName     string // Of a purchase
+	Balance       int64  // Left after the transaction and its round-up
+}
+
+// ActivityDigest summarizes for the holder of a wallet in digest mode what they spent
+// over a day
+type ActivityDigest struct {
+	UserID       uuid.UUID
+	Locale       string
+	FestivalID   uuid.UUID
+	FestivalName string
+	CurrencyName string
+	WalletID     uuid.UUID
+	From         time.Time // Period summarized, in the festival time zone
+	To           time.Time
+	TotalSpent   int64 // Purchases net of their refunds, in cents
+	Purchases    int
+	TopStands    []StandSpending // Most spent first
+	Balance      int64
+}
+
+// StandSpending is what a wallet spent at a stand over a period
+type StandSpending struct {
+	StandID   *uuid.UUID
+	StandName string
+	Spent     int64 // Net of refunds, in cents
+	Purchases int
+}
+
+// DigestFestival is a festival whose wallets get activity digests
+type DigestFestival struct {
+	ID           uuid.UUID
+	Name         string
+	Timezone     string
+	CurrencyName string
+}
+
+// DigestWallet is a wallet due for an activity digest, with the push preferences of its
+// holder
+type DigestWallet struct {
+	WalletID          uuid.UUID
+	UserID            uuid.UUID
+	Balance           int64
+	LastDigestAt      *time.Time // End of the period of the last digest, or when digest mode was chosen
+	Locale            string
+	QuietHoursEnabled bool
+	QuietHoursStart   string // HH:MM, read in the festival time zone
+	QuietHoursEnd     string
+}
+
+// SetActivityNotifier sets the notifier pushing the purchases and top-ups of wallets,
+// or their nightly digest, to their holders
+func (s *Service) SetActivityNotifier(notifier ActivityNotifier) {
+	s.activityNotifier = notifier
+}
+
+// SetActivityPush changes how a user is told about the activity of their wallet in a
+// festival. Choosing digest mode starts the first digest from now, so that it does not
+// repeat the transactions already pushed one by one.
+func (s *Service) SetActivityPush(ctx context.Context, userID, festivalID uuid.UUID, mode ActivityPushMode) (*Wallet, error) {
+	if !mode.IsValid() {
+		return nil, ErrInvalidActivityPush
+	}
+
+	wallet, err := s.GetOrCreateWallet(ctx, userID, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.ActivityPush == mode {
+		return wallet, nil
+	}
+
+	now := time.Now()
+	if mode == ActivityPushDigest {
+		wallet.LastDigestAt = &now
+	}
+	wallet.ActivityPush = mode
+	wallet.UpdatedAt = now
+
+	if err := s.repo.SetActivityPush(ctx, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// notifyActivity pushes a purchase or top-up to the holder of a wallet in instant mode.
+// A failed push does not fail the transaction.
+func (s *Service) notifyActivity(ctx context.Context, tx *Transaction) {
+	if s.activityNotifier == nil {
+		return
+	}
+
+	holder, err := s.repo.GetWalletHolder(ctx, tx.WalletID)
+	if err != nil {
+		log.Warn().Err(err).Str("wallet_id", tx.WalletID.String()).Msg("Failed to get holder to push wallet activity")
+		return
+	}
+	if holder == nil || holder.ActivityPush != ActivityPushInstant {
+		return
+	}
+
+	notice := ActivityNotice{
+		UserID:        holder.UserID,
+		Locale:        holder.Locale,
+		FestivalID:    holder.FestivalID,
+		FestivalName:  holder.FestivalName,
+		CurrencyName:  holder.CurrencyName,
+		TransactionID: tx.ID,
+		Type:          tx.Type,
+		Amount:        tx.Amount,
+		Balance:       tx.BalanceAfter,
+	}
+	if notice.Amount < 0 {
+		notice.Amount = -notice.Amount
+	}
+	if tx.RoundUp != nil {
+		notice.Balance -= tx.RoundUp.Amount
+	}
+	if tx.StandID != nil {
+		names, err := s.repo.GetStandNames(ctx, []uuid.UUID{*tx.StandID})
+		if err != nil {
+			log.Warn().Err(err).Str("transaction_id", tx.ID.String()).Msg("Failed to get stand name of wallet activity")
+		}
+		notice.StandName = names[*tx.StandID]
+	}
+
+	if err := s.activityNotifier.NotifyWalletActivity(ctx, notice); err != nil {
+		log.Warn().Err(err).Str("wallet_id", tx.WalletID.String()).Msg("Failed to push wallet activity")
+	}
+}
+
+// HandleSendActivityDigests is the worker handler of TypeSendActivityDigests
+func (s *Service) HandleSendActivityDigests(ctx context.Context, t *asynq.Task) error {
+	sent, err := s.SendDueDigests(ctx)
+	if err != nil {
+		return err
+	}
+	if sent > 0 {
+		log.Info().Int("digests", sent).Msg("Sent wallet activity digests")
+	}
+	return nil
+}
+
+// SendDueDigests pushes the nightly digests due to the holders of wallets in digest
+// mode, returning how many were sent. A digest is due at ActivityDigestHour in the
+// festival time zone; when the quiet hours of the holder cover that time, it waits
+// until they end. Festivals on a test clock are due at their virtual time.
+func (s *Service) SendDueDigests(ctx context.Context) (int, error) {
+	if s.activityNotifier == nil {
+		return 0, nil
+	}
+
+	runs, err := clock.Runs(ctx, s.clock, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get test clocks: %w", err)
+	}
+
+	sent := 0
+	for _, run := range runs {
+		festivals, err := s.repo.ListDigestFestivals(ctx, run.Scope)
+		if err != nil {
+			return sent, err
+		}
+		for _, festival := range festivals {
+			n, err := s.sendFestivalDigests(ctx, festival, run.Now)
+			sent += n
+			if err != nil {
+				return sent, err
+			}
+		}
+	}
+	return sent, nil
+}
+
+// sendFestivalDigests pushes the digests due in a festival at now
+func (s *Service) sendFestivalDigests(ctx context.Context, festival DigestFestival, now time.Time) (int, error) {
+	loc := tz.Load(festival.Timezone)
+	from, to := digestPeriod(now, loc)
+
+	wallets, err := s.repo.ListDueDigestWallets(ctx, festival.ID, to)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, w := range wallets {
+		if w.QuietHoursEnabled && inQuietHours(now.In(loc), w.QuietHoursStart, w.QuietHoursEnd) {
+			// Left due until the quiet hours end
+			continue
+		}
+
+		// Claimed first, so that concurrent runs do not push the digest twice
+		claimed, err := s.repo.MarkDigestSent(ctx, w.WalletID, to)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		start := from
+		if w.LastDigestAt != nil && w.LastDigestAt.After(start) {
+			start = *w.LastDigestAt
+		}
+		spending, err := s.repo.GetDigestSpending(ctx, w.WalletID, start, to)
+		if err != nil {
+			log.Warn().Err(err).Str("wallet_id", w.WalletID.String()).Msg("Failed to get spending of wallet activity digest")
+			continue
+		}
+
+		digest := buildDigest(festival, w, spending, start.In(loc), to)
+		if digest.Purchases == 0 {
+			// Nothing bought that day, nothing to summarize
+			continue
+		}
+		if err := s.activityNotifier.NotifyActivityDigest(ctx, digest); err != nil {
+			log.Warn().Err(err).Str("wallet_id", w.WalletID.String()).Msg("Failed to push wallet activity digest")
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// buildDigest sums the spending per stand of a wallet into its digest
+func buildDigest(festival DigestFestival, w DigestWallet, spending []StandSpending, from, to time.Time) ActivityDigest {
+	digest := ActivityDigest{
+		UserID:       w.UserID,
+		Locale:       w.Locale,
+		FestivalID:   festival.ID,
+		FestivalName: festival.Name,
+		CurrencyName: festival.CurrencyName,
+		WalletID:     w.WalletID,
+		From:         from,
+		To:           to,
+		Balance:      w.Balance,
+		TopStands:    []StandSpending{},
+	}
+	for _, stand := range spending {
+		digest.TotalSpent += stand.Spent
+		digest.Purchases += stand.Purchases
+		if stand.Spent > 0 && len(digest.TopStands) < ActivityDigestTopStands {
+			digest.TopStands = append(digest.TopStands, stand)
+		}
+	}
+	return digest
+}
+
+// digestPeriod returns the 24 hours summarized by the last digest due at now, ending at
+// ActivityDigestHour in loc
+func digestPeriod(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := now.In(loc)
+	to := time.Date(local.Year(), local.Month(), local.Day(), ActivityDigestHour, 0, 0, 0, loc)
+	if to.After(now) {
+		to = time.Date(local.Year(), local.Month(), local.Day()-1, ActivityDigestHour, 0, 0, 0, loc)
+	}
+	from := time.Date(to.Year(), to.Month(), to.Day()-1, ActivityDigestHour, 0, 0, 0, loc)
+	return from, to
+}
+
+// inQuietHours tells whether a local time falls within quiet hours from start to end,
+// both HH:MM; quiet hours ending before they start last over midnight
+func inQuietHours(local time.Time, start, end string) bool {
+	from, err := tz.ParseDayStart(start)
+	if err != nil {
+		return false
+	}
+	until, err := tz.ParseDayStart(end)
+	if err != nil || from == until {
+		return false
+	}
+
+	at := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if from < until {
+		return at >= from && at < until
+	}
+	return at >= from || at < until
+}
@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeActivityNotifier struct {
+	notices []ActivityNotice
+	digests []ActivityDigest
+}
+
+func (n *fakeActivityNotifier) NotifyWalletActivity(ctx context.Context, notice ActivityNotice) error {
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+func (n *fakeActivityNotifier) NotifyActivityDigest(ctx context.Context, digest ActivityDigest) error {
+	n.digests = append(n.digests, digest)
+	return nil
+}
+
+func TestDigestPeriod(t *testing.T) {
+	brussels, err := time.LoadLocation("Europe/Brussels")
+	require.NoError(t, err)
+
+	// After the digest hour, the digest of the day is due
+	from, to := digestPeriod(time.Date(2026, 7, 10, 23, 30, 0, 0, brussels), brussels)
+	assert.Equal(t, time.Date(2026, 7, 9, 22, 0, 0, 0, brussels), from)
+	assert.Equal(t, time.Date(2026, 7, 10, 22, 0, 0, 0, brussels), to)
+
+	// Before it, the digest of the day before still is, e.g. after the quiet hours
+	from, to = digestPeriod(time.Date(2026, 7, 10, 8, 0, 0, 0, brussels), brussels)
+	assert.Equal(t, time.Date(2026, 7, 8, 22, 0, 0, 0, brussels), from)
+	assert.Equal(t, time.Date(2026, 7, 9, 22, 0, 0, 0, brussels), to)
+
+	// The hour is local: 22:00 in Brussels is 20:00 UTC in summer
+	_, to = digestPeriod(time.Date(2026, 7, 10, 20, 30, 0, 0, time.UTC), brussels)
+	assert.Equal(t, time.Date(2026, 7, 10, 20, 0, 0, 0, time.UTC), to.UTC())
+}
+
+func TestInQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 7, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		local      time.Time
+		start, end string
+		want       bool
+	}{
+		{"over midnight, evening", at(22, 0), "22:00", "08:00", true},
+		{"over midnight, night", at(3, 15), "22:00", "08:00", true},
+		{"over midnight, end excluded", at(8, 0), "22:00", "08:00", false},
+		{"over midnight, day", at(14, 0), "22:00", "08:00", false},
+		{"same day, within", at(14, 0), "13:00", "15:30", true},
+		{"same day, before", at(12, 59), "13:00", "15:30", false},
+		{"empty window", at(22, 0), "22:00", "22:00", false},
+		{"invalid start", at(22, 0), "late", "08:00", false},
+		{"invalid end", at(22, 0), "22:00", "25:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, inQuietHours(tt.local, tt.start, tt.end))
+		})
+	}
+}
+
+func TestService_SendFestivalDigests(t *testing.T) {
+	brussels, err := time.LoadLocation("Europe/Brussels")
+	require.NoError(t, err)
+	now := time.Date(2026, 7, 10, 22, 5, 0, 0, brussels)
+	from := time.Date(2026, 7, 9, 22, 0, 0, 0, brussels)
+	to := time.Date(2026, 7, 10, 22, 0, 0, 0, brussels)
+	festival := DigestFestival{ID: uuid.New(), Name: "Summer Fest", Timezone: "Europe/Brussels", CurrencyName: "Jetons"}
+
+	chosenAt := time.Date(2026, 7, 10, 10, 0, 0, 0, brussels)
+	spender := DigestWallet{WalletID: uuid.New(), UserID: uuid.New(), Balance: 1250, Locale: "fr"}
+	quiet := DigestWallet{WalletID: uuid.New(), UserID: uuid.New(), QuietHoursEnabled: true, QuietHoursStart: "21:00", QuietHoursEnd: "07:00"}
+	sent := DigestWallet{WalletID: uuid.New(), UserID: uuid.New()}
+	idle := DigestWallet{WalletID: uuid.New(), UserID: uuid.New()}
+	newcomer := DigestWallet{WalletID: uuid.New(), UserID: uuid.New(), LastDigestAt: &chosenAt, Balance: 400}
+
+	bar, food, merch, refunded := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	mockRepo := NewMockRepository()
+	mockRepo.On("ListDueDigestWallets", mock.Anything, festival.ID, to).Return([]DigestWallet{spender, quiet, sent, idle, newcomer}, nil)
+	mockRepo.On("MarkDigestSent", mock.Anything, spender.WalletID, to).Return(true, nil)
+	mockRepo.On("MarkDigestSent", mock.Anything, sent.WalletID, to).Return(false, nil)
+	mockRepo.On("MarkDigestSent", mock.Anything, idle.WalletID, to).Return(true, nil)
+	mockRepo.On("MarkDigestSent", mock.Anything, newcomer.WalletID, to).Return(true, nil)
+	mockRepo.On("GetDigestSpending", mock.Anything, spender.WalletID, from, to).Return([]StandSpending{
+		{StandID: &bar, StandName: "Main Bar", Spent: 1800, Purchases: 4},
+		{StandID: &food, StandName: "Food Court", Spent: 950, Purchases: 1},
+		{StandID: &merch, StandName: "Merch Tent", Spent: 500, Purchases: 1},
+		{StandID: uuidPtr(uuid.New()), StandName: "Crêpes", Spent: 300, Purchases: 1},
+		{StandID: &refunded, StandName: "Cocktails", Spent: 0, Purchases: 1},
+	}, nil)
+	mockRepo.On("GetDigestSpending", mock.Anything, idle.WalletID, from, to).Return([]StandSpending{}, nil)
+	mockRepo.On("GetDigestSpending", mock.Anything, newcomer.WalletID, chosenAt, to).Return([]StandSpending{
+		{StandID: &bar, StandName: "Main Bar", Spent: 600, Purchases: 2},
+	}, nil)
+
+	notifier := &fakeActivityNotifier{}
+	service := NewService(mockRepo, testSecretKey)
+	service.SetActivityNotifier(notifier)
+
+	count, err := service.sendFestivalDigests(context.Background(), festival, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	require.Len(t, notifier.digests, 2)
+	digest := notifier.digests[0]
+	assert.Equal(t, spender.UserID, digest.UserID)
+	assert.Equal(t, "fr", digest.Locale)
+	assert.Equal(t, "Summer Fest", digest.FestivalName)
+	assert.Equal(t, "Jetons", digest.CurrencyName)
+	assert.Equal(t, int64(3550), digest.TotalSpent)
+	assert.Equal(t, 8, digest.Purchases)
+	assert.Equal(t, int64(1250), digest.Balance)
+	assert.True(t, digest.From.Equal(from))
+	assert.True(t, digest.To.Equal(to))
+	require.Len(t, digest.TopStands, ActivityDigestTopStands)
+	assert.Equal(t, "Main Bar", digest.TopStands[0].StandName)
+	assert.Equal(t, "Merch Tent", digest.TopStands[2].StandName)
+
+	// The first digest after choosing digest mode starts when it was chosen
+	assert.Equal(t, newcomer.UserID, notifier.digests[1].UserID)
+	assert.True(t, notifier.digests[1].From.Equal(chosenAt))
+	assert.Equal(t, int64(600), notifier.digests[1].TotalSpent)
+
+	// The holder in quiet hours is left due, without being claimed
+	mockRepo.AssertNotCalled(t, "MarkDigestSent", mock.Anything, quiet.WalletID, mock.Anything)
+	// A digest claimed by another run is not summed again
+	mockRepo.AssertNotCalled(t, "GetDigestSpending", mock.Anything, sent.WalletID, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_SendDueDigests_WithoutNotifier(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, testSecretKey)
+
+	count, err := service.SendDueDigests(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	mockRepo.AssertNotCalled(t, "ListDigestFestivals", mock.Anything, mock.Anything)
+}
+
+func TestService_SetActivityPush(t *testing.T) {
+	userID := uuid.New()
+	festivalID := uuid.New()
+	wallet := &Wallet{ID: uuid.New(), UserID: &userID, FestivalID: festivalID, ActivityPush: ActivityPushInstant}
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(wallet, nil)
+	mockRepo.On("SetActivityPush", mock.Anything, wallet).Return(nil).Once()
+	service := NewService(mockRepo, testSecretKey)
+
+	_, err := service.SetActivityPush(context.Background(), userID, festivalID, ActivityPushMode("HOURLY"))
+	assert.ErrorIs(t, err, ErrInvalidActivityPush)
+
+	updated, err := service.SetActivityPush(context.Background(), userID, festivalID, ActivityPushDigest)
+	require.NoError(t, err)
+	assert.Equal(t, ActivityPushDigest, updated.ActivityPush)
+	require.NotNil(t, updated.LastDigestAt, "first digest starts when chosen")
+	assert.WithinDuration(t, time.Now(), *updated.LastDigestAt, time.Minute)
+
+	// Choosing the current mode again saves nothing
+	_, err = service.SetActivityPush(context.Background(), userID, festivalID, ActivityPushDigest)
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ProcessPayment_PushesActivity(t *testing.T) {
+	walletID := uuid.New()
+	standID := uuid.New()
+	userID := uuid.New()
+	festivalID := uuid.New()
+
+	setup := func(mode ActivityPushMode) (*MockRepository, *fakeActivityNotifier, *Service) {
+		mockRepo := NewMockRepository()
+		mockRepo.On("ProcessPayment", mock.Anything, walletID, int64(450), mock.AnythingOfType("*wallet.Transaction")).
+			Run(func(args mock.Arguments) {
+				tx := args.Get(3).(*Transaction)
+				tx.Amount = -450
+				tx.BalanceBefore = 2000
+				tx.BalanceAfter = 1550
+			}).Return(nil)
+		mockRepo.On("GetWalletHolder", mock.Anything, walletID).Return(&WalletHolder{
+			UserID:       userID,
+			Locale:       "nl",
+			FestivalID:   festivalID,
+			FestivalName: "Summer Fest",
+			CurrencyName: "Jetons",
+			ActivityPush: mode,
+		}, nil)
+		mockRepo.On("GetStandNames", mock.Anything, []uuid.UUID{standID}).Return(map[uuid.UUID]string{standID: "Main Bar"}, nil)
+
+		notifier := &fakeActivityNotifier{}
+		service := NewService(mockRepo, testSecretKey)
+		service.SetActivityNotifier(notifier)
+		return mockRepo, notifier, service
+	}
+	req := PaymentRequest{WalletID: walletID, Amount: 450, StandID: standID}
+
+	_, notifier, service := setup(ActivityPushInstant)
+	tx, err := service.ProcessPayment(context.Background(), req, uuid.New())
+	require.NoError(t, err)
+	require.Len(t, notifier.notices, 1)
+	notice := notifier.notices[0]
+	assert.Equal(t, userID, notice.UserID)
+	assert.Equal(t, festivalID, notice.FestivalID)
+	assert.Equal(t, tx.ID, notice.TransactionID)
+	assert.Equal(t, TransactionTypePurchase, notice.Type)
+	assert.Equal(t, int64(450), notice.Amount)
+	assert.Equal(t, int64(1550), notice.Balance)
+	assert.Equal(t, "Main Bar", notice.StandName)
+
+	// Holders in digest mode get the purchase in their nightly digest instead
+	mockRepo, notifier, service := setup(ActivityPushDigest)
+	_, err = service.ProcessPayment(context.Background(), req, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, notifier.notices)
+	mockRepo.AssertNotCalled(t, "GetStandNames", mock.Anything, mock.Anything)
+}
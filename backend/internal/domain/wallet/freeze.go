@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeThawWallets is the worker task unfreezing the wallets whose automatic unfreeze is due
+const TypeThawWallets = "wallet:thaw_frozen"
+
+// FreezeNotifier tells wallet holders that their wallet was frozen or unfrozen;
+// satisfied by jobs.EmailQueue
+type FreezeNotifier interface {
+	NotifyWalletFreeze(ctx context.Context, notice FreezeNotice) error
+}
+
+// FreezeNotice tells the holder of a wallet that it was frozen or unfrozen
+type FreezeNotice struct {
+	To           string
+	Locale       string
+	FestivalID   uuid.UUID
+	FestivalName string
+	Frozen       bool         // Otherwise unfrozen
+	Reason       FreezeReason // Of the freeze
+	Until        *time.Time   // Scheduled automatic unfreeze of a freeze
+	Automatic    bool         // Unfrozen by the schedule rather than by staff
+}
+
+// WalletHolder is the contact of the user a wallet belongs to
+type WalletHolder struct {
+	UserID       uuid.UUID
+	Email        string
+	Locale       string
+	FestivalID   uuid.UUID
+	FestivalName string
+	CurrencyName string
+	ActivityPush ActivityPushMode // Of the wallet
+}
+
+// SetClock sets the clock telling the time of festivals, so that the scheduled unfreezes
+// of sandbox festivals happen at their virtual time
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetFreezeNotifier sets the notifier telling holders about freezes of their wallet
+func (s *Service) SetFreezeNotifier(notifier FreezeNotifier) {
+	s.freezeNotifier = notifier
+}
+
+// FreezeWallet freezes a wallet, e.g. after a lost wristband or a suspected fraud.
+// Payments, top-ups and offline transactions are refused until it is unfrozen, by staff
+// or automatically at the time requested. Its QR material is rotated, so that the codes
+// shown before cannot be used once it is unfrozen. Freezing a frozen wallet again
+// replaces its reason and schedule.
+func (s *Service) FreezeWallet(ctx context.Context, walletID uuid.UUID, req FreezeWalletRequest, staffID *uuid.UUID) (*Wallet, error) {
+	now := time.Now()
+	until, err := freezeUntil(req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.repo.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, errors.ErrNotFound
+	}
+	if wallet.Status == WalletStatusClosed {
+		return nil, ErrFreezeClosedWallet
+	}
+
+	if wallet.Status != WalletStatusFrozen {
+		wallet.Status = WalletStatusFrozen
+		wallet.QRGeneration++
+		wallet.FrozenAt = &now
+	}
+	wallet.FreezeReason = req.Reason
+	wallet.FreezeNote = req.Note
+	wallet.FrozenBy = staffID
+	wallet.FrozenUntil = until
+	wallet.UpdatedAt = now
+
+	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
+		return nil, fmt.Errorf("failed to freeze wallet: %w", err)
+	}
+
+	s.walletChanged(ctx, wallet.ID)
+	s.notifyFreeze(ctx, wallet, FreezeNotice{Frozen: true, Reason: wallet.FreezeReason, Until: wallet.FrozenUntil})
+	return wallet, nil
+}
+
+// UnfreezeWallet unfreezes a frozen wallet before its automatic unfreeze, if any
+func (s *Service) UnfreezeWallet(ctx context.Context, walletID uuid.UUID) (*Wallet, error) {
+	wallet, err := s.repo.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, errors.ErrNotFound
+	}
+	if wallet.Status != WalletStatusFrozen {
+		return nil, ErrWalletNotFrozen
+	}
+
+	reason := wallet.FreezeReason
+	thaw(wallet, time.Now())
+
+	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
+		return nil, fmt.Errorf("failed to unfreeze wallet: %w", err)
+	}
+
+	s.walletChanged(ctx, wallet.ID)
+	s.notifyFreeze(ctx, wallet, FreezeNotice{Reason: reason})
+	return wallet, nil
+}
+
+// HandleThawWallets is the worker handler of TypeThawWallets
+func (s *Service) HandleThawWallets(ctx context.Context, t *asynq.Task) error {
+	thawed, err := s.ThawDue(ctx)
+	if err != nil {
+		return err
+	}
+	if thawed > 0 {
+		log.Info().Int("wallets", thawed).Msg("Unfroze wallets on schedule")
+	}
+	return nil
+}
+
+// ThawDue unfreezes the wallets whose automatic unfreeze is due, returning how many.
+// Festivals on a test clock are due at their virtual time.
+func (s *Service) ThawDue(ctx context.Context) (int, error) {
+	runs, err := clock.Runs(ctx, s.clock, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get test clocks: %w", err)
+	}
+
+	var wallets []Wallet
+	for _, run := range runs {
+		thawed, err := s.repo.ThawDueWallets(ctx, run.Now, run.Scope)
+		if err != nil {
+			return 0, err
+		}
+		wallets = append(wallets, thawed...)
+	}
+
+	for i := range wallets {
+		s.walletChanged(ctx, wallets[i].ID)
+		s.notifyFreeze(ctx, &wallets[i], FreezeNotice{Reason: wallets[i].FreezeReason, Automatic: true})
+	}
+	return len(wallets), nil
+}
+
+// freezeUntil returns when a freeze requested is lifted automatically, nil for never
+func freezeUntil(req FreezeWalletRequest, now time.Time) (*time.Time, error) {
+	if req.Until != nil && req.ThawAfterHours > 0 {
+		return nil, ErrFreezeThawConflict
+	}
+
+	until := req.Until
+	if req.ThawAfterHours > 0 {
+		at := now.Add(time.Duration(req.ThawAfterHours) * time.Hour)
+		until = &at
+	}
+	if until != nil && (!until.After(now) || until.After(now.Add(MaxFreezeDuration))) {
+		return nil, ErrFreezeUntilInvalid
+	}
+	return until, nil
+}
+
+// thaw makes a frozen wallet active again
+func thaw(wallet *Wallet, now time.Time) {
+	wallet.Status = WalletStatusActive
+	wallet.FreezeReason = ""
+	wallet.FreezeNote = ""
+	wallet.FrozenAt = nil
+	wallet.FrozenBy = nil
+	wallet.FrozenUntil = nil
+	wallet.UpdatedAt = now
+}
+
+// notifyFreeze emails the holder of a wallet about a freeze or unfreeze. Anonymous
+// wallets have nobody to notify, and a failed notice does not fail the freeze.
+func (s *Service) notifyFreeze(ctx context.Context, wallet *Wallet, notice FreezeNotice) {
+	if s.freezeNotifier == nil || wallet.IsAnonymous() {
+		return
+	}
+
+	holder, err := s.repo.GetWalletHolder(ctx, wallet.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("wallet_id", wallet.ID.String()).Msg("Failed to get holder to notify of wallet freeze")
+		return
+	}
+	if holder == nil || holder.Email == "" {
+		return
+	}
+
+	notice.To = holder.Email
+	notice.Locale = holder.Locale
+	notice.FestivalID = wallet.FestivalID
+	notice.FestivalName = holder.FestivalName
+	if err := s.freezeNotifier.NotifyWalletFreeze(ctx, notice); err != nil {
+		log.Warn().Err(err).Str("wallet_id", wallet.ID.String()).Msg("Failed to notify holder of wallet freeze")
+	}
+}
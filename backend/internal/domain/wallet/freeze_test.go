@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFreezeNotifier struct {
+	notices []FreezeNotice
+}
+
+func (n *fakeFreezeNotifier) NotifyWalletFreeze(ctx context.Context, notice FreezeNotice) error {
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+func TestService_FreezeWallet_ReasonAndSchedule(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	festivalID := uuid.New()
+	staffID := uuid.New()
+
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(&Wallet{
+		ID:         walletID,
+		UserID:     uuidPtr(uuid.New()),
+		FestivalID: festivalID,
+		Status:     WalletStatusActive,
+	}, nil)
+	mockRepo.On("UpdateWallet", mock.Anything, mock.AnythingOfType("*wallet.Wallet")).Return(nil)
+	mockRepo.On("GetWalletHolder", mock.Anything, walletID).Return(&WalletHolder{
+		Email:        "holder@example.com",
+		Locale:       "fr",
+		FestivalName: "Summer Fest",
+	}, nil)
+
+	notifier := &fakeFreezeNotifier{}
+	service := NewService(mockRepo, testSecretKey)
+	service.SetFreezeNotifier(notifier)
+
+	frozen, err := service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{
+		Reason:         FreezeReasonSuspectedFraud,
+		Note:           "Several refused payments at the bar",
+		ThawAfterHours: 24,
+	}, &staffID)
+	require.NoError(t, err)
+
+	assert.Equal(t, WalletStatusFrozen, frozen.Status)
+	assert.Equal(t, FreezeReasonSuspectedFraud, frozen.FreezeReason)
+	assert.Equal(t, &staffID, frozen.FrozenBy)
+	assert.Equal(t, 1, frozen.QRGeneration, "QR material rotated")
+	require.NotNil(t, frozen.FrozenAt)
+	require.NotNil(t, frozen.FrozenUntil)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *frozen.FrozenUntil, time.Minute)
+
+	require.Len(t, notifier.notices, 1)
+	notice := notifier.notices[0]
+	assert.True(t, notice.Frozen)
+	assert.Equal(t, "holder@example.com", notice.To)
+	assert.Equal(t, "fr", notice.Locale)
+	assert.Equal(t, festivalID, notice.FestivalID)
+	assert.Equal(t, FreezeReasonSuspectedFraud, notice.Reason)
+	assert.Equal(t, frozen.FrozenUntil, notice.Until)
+
+	// Freezing again replaces the reason and schedule without rotating the QR material again
+	frozen, err = service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{Reason: FreezeReasonChargeback}, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, FreezeReasonChargeback, frozen.FreezeReason)
+	assert.Nil(t, frozen.FrozenUntil)
+	assert.Equal(t, 1, frozen.QRGeneration)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_FreezeWallet_Rejected(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(&Wallet{ID: walletID, Status: WalletStatusClosed}, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+
+	_, err := service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{Reason: FreezeReasonStolen}, nil)
+	assert.ErrorIs(t, err, ErrFreezeClosedWallet)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{Reason: FreezeReasonStolen, Until: &past}, nil)
+	assert.ErrorIs(t, err, ErrFreezeUntilInvalid)
+
+	tooFar := time.Now().Add(MaxFreezeDuration + time.Hour)
+	_, err = service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{Reason: FreezeReasonStolen, Until: &tooFar}, nil)
+	assert.ErrorIs(t, err, ErrFreezeUntilInvalid)
+
+	future := time.Now().Add(time.Hour)
+	_, err = service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{Reason: FreezeReasonStolen, Until: &future, ThawAfterHours: 2}, nil)
+	assert.ErrorIs(t, err, ErrFreezeThawConflict)
+
+	mockRepo.AssertNotCalled(t, "UpdateWallet", mock.Anything, mock.Anything)
+}
+
+func TestService_UnfreezeWallet_NotFrozen(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(&Wallet{ID: walletID, Status: WalletStatusClosed}, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+
+	_, err := service.UnfreezeWallet(context.Background(), walletID)
+	assert.ErrorIs(t, err, ErrWalletNotFrozen, "closed wallets are not reopened")
+	mockRepo.AssertNotCalled(t, "UpdateWallet", mock.Anything, mock.Anything)
+}
+
+func TestService_ThawDue(t *testing.T) {
+	mockRepo := NewMockRepository()
+	claimed := Wallet{ID: uuid.New(), UserID: uuidPtr(uuid.New()), FestivalID: uuid.New(), Status: WalletStatusActive, FreezeReason: FreezeReasonLostWristband}
+	anonymous := Wallet{ID: uuid.New(), FestivalID: uuid.New(), Status: WalletStatusActive, FreezeReason: FreezeReasonOther}
+
+	mockRepo.On("ThawDueWallets", mock.Anything, mock.AnythingOfType("time.Time"), mock.AnythingOfType("clock.Scope")).Return([]Wallet{claimed, anonymous}, nil)
+	mockRepo.On("GetWalletHolder", mock.Anything, claimed.ID).Return(&WalletHolder{Email: "holder@example.com", FestivalName: "Summer Fest"}, nil)
+
+	notifier := &fakeFreezeNotifier{}
+	service := NewService(mockRepo, testSecretKey)
+	service.SetFreezeNotifier(notifier)
+
+	thawed, err := service.ThawDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, thawed)
+
+	require.Len(t, notifier.notices, 1, "anonymous wallets have nobody to notify")
+	assert.False(t, notifier.notices[0].Frozen)
+	assert.True(t, notifier.notices[0].Automatic)
+	assert.Equal(t, FreezeReasonLostWristband, notifier.notices[0].Reason)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -1,7 +1,9 @@
 package wallet
 
 import (
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -29,28 +31,51 @@ func (h *Handler) SetFestivalConfig(exchangeRate float64, currencyName string) {
 	h.currencyName = currencyName
 }
 
-func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+// RegisterRoutes registers the wallet routes. The verify handlers check the signature
+// of the debits and credits made from POS terminals: payments, refunds and top-ups.
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, verify ...gin.HandlerFunc) {
 	// User wallet routes
 	me := r.Group("/me")
 	{
 		me.GET("/wallets", h.GetMyWallets)
 		me.GET("/wallets/:festivalId", h.GetMyWallet)
 		me.POST("/wallets/:festivalId", h.CreateMyWallet)
+		me.POST("/wallets/claim", h.ClaimWallet)
 		me.GET("/wallets/:festivalId/qr", h.GenerateQR)
+		me.GET("/wallets/:festivalId/qr/material", h.GetMyQRMaterial)
+		me.POST("/wallets/:festivalId/qr/rotate", h.RotateMyQRMaterial)
 		me.GET("/wallets/:festivalId/transactions", h.GetMyTransactions)
+		me.GET("/wallets/:festivalId/activity", h.GetMyActivity)
+		me.GET("/wallets/:festivalId/statement", h.GetMyStatement)
+		me.PUT("/wallets/:festivalId/activity-push", h.UpdateMyActivityPush)
+		me.GET("/wallet-merges", h.GetMyMerges)
+		me.POST("/wallet-merges/:id/confirm", h.ConfirmMyMerge)
+		me.POST("/wallet-merges/:id/cancel", h.CancelMyMerge)
 	}
 
 	// Staff wallet routes (requires staff role)
 	wallets := r.Group("/wallets")
 	{
+		wallets.POST("/anonymous", h.CreateAnonymousWallet)
 		wallets.GET("/:id", h.GetWallet)
-		wallets.POST("/:id/topup", h.TopUp)
+		wallets.Group("", verify...).POST("/:id/topup", h.TopUp)
 		wallets.POST("/:id/freeze", h.FreezeWallet)
 		wallets.POST("/:id/unfreeze", h.UnfreezeWallet)
+		wallets.POST("/:id/replace-wristband", h.ReplaceWristband)
+		wallets.GET("/:id/wristband-replacements", h.GetWristbandReplacements)
+	}
+
+	// Wallet merge routes (staff only)
+	merges := r.Group("/wallet-merges")
+	{
+		merges.POST("", h.InitiateMerge)
+		merges.GET("/:id", h.GetMerge)
+		merges.POST("/:id/confirm", h.ConfirmMerge)
+		merges.POST("/:id/cancel", h.CancelMerge)
 	}
 
 	// Payment routes (staff only)
-	payments := r.Group("/payments")
+	payments := r.Group("/payments", verify...)
 	{
 		payments.POST("", h.ProcessPayment)
 		payments.POST("/validate-qr", h.ValidateQR)
@@ -58,6 +83,11 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	}
 }
 
+// RegisterFestivalRoutes registers festival-scoped routes for POS devices (staff only)
+func (h *Handler) RegisterFestivalRoutes(r *gin.RouterGroup) {
+	r.GET("/wallet-qr/offline-spec", h.GetQROfflineSpec)
+}
+
 // GetMyWallets returns all wallets for the current user
 // @Summary Get user's wallets
 // @Description Get all wallets belonging to the authenticated user
@@ -159,11 +189,11 @@ func (h *Handler) CreateMyWallet(c *gin.Context) {
 
 // GenerateQR generates a QR code payload for the user's wallet
 // @Summary Generate QR code for wallet
-// @Description Generate a signed QR code payload for cashless payments
+// @Description Generate the signed QR code payload of the current rotation step for cashless payments. Codes rotate every period and are refused after expiresAt; apps that sign codes themselves use the QR material instead.
 // @Tags wallets
 // @Produce json
 // @Param festivalId path string true "Festival ID" format(uuid)
-// @Success 200 {object} response.Response{data=object{qrCode=string,balance=int64}} "QR code payload"
+// @Success 200 {object} response.Response{data=object{qrCode=string,balance=int64,expiresAt=string}} "QR code payload"
 // @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
@@ -197,11 +227,122 @@ func (h *Handler) GenerateQR(c *gin.Context) {
 	}
 
 	response.OK(c, gin.H{
-		"qrCode":  qrPayload,
-		"balance": wallet.Balance,
+		"qrCode":    qrPayload,
+		"balance":   wallet.Balance,
+		"expiresAt": h.service.QRCodeExpiresAt(time.Now()).Format(time.RFC3339),
 	})
 }
 
+// GetMyQRMaterial returns the material the wallet app signs rotating QR codes with
+// @Summary Get wallet QR material
+// @Description Get the seed and rotation period the wallet app signs QR codes with, so that codes can be shown without a connection. The material stays valid until it is rotated; fetch it again after refreshAt when online.
+// @Tags wallets
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=QRMaterial} "QR material"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID or wallet not active"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallets/{festivalId}/qr/material [get]
+func (h *Handler) GetMyQRMaterial(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	wallet, err := h.service.GetOrCreateWallet(c.Request.Context(), userID, festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	material, err := h.service.GetQRMaterial(c.Request.Context(), wallet.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, material)
+}
+
+// RotateMyQRMaterial revokes the QR material of the user's wallet
+// @Summary Rotate wallet QR material
+// @Description Revoke the QR material of the wallet and every code signed with it, e.g. after losing a phone or sharing a screenshot, and get new material. POS devices refuse the revoked codes once they refresh their offline spec.
+// @Tags wallets
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=QRMaterial} "New QR material"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID or wallet not active"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallets/{festivalId}/qr/rotate [post]
+func (h *Handler) RotateMyQRMaterial(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	wallet, err := h.service.GetOrCreateWallet(c.Request.Context(), userID, festivalID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	material, err := h.service.RotateQRMaterial(c.Request.Context(), wallet.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, material)
+}
+
+// GetQROfflineSpec returns what POS devices need to verify wallet QR codes offline
+// @Summary Get wallet QR offline spec
+// @Description Get the festival keys, rotation parameters, revoked wallets and replaced wristbands POS devices verify wallet QR codes and wristbands with while offline. Devices refresh it whenever online and stop accepting codes offline after validUntil.
+// @Tags payments
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Success 200 {object} response.Response{data=QROfflineSpec} "Offline spec"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Staff only"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-qr/offline-spec [get]
+func (h *Handler) GetQROfflineSpec(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	spec, err := h.service.GetQROfflineSpec(c.Request.Context(), festivalID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, spec)
+}
+
 // GetMyTransactions returns transactions for the user's wallet
 // @Summary Get wallet transactions
 // @Description Get paginated list of transactions for the user's wallet
@@ -257,6 +398,183 @@ func (h *Handler) GetMyTransactions(c *gin.Context) {
 	})
 }
 
+// GetMyActivity returns the activity history of the current user's wallet
+// @Summary Get my wallet activity
+// @Description List the wallet history for the app's history screen: each item is a transaction with the refunds and corrections that amended it, with stand and product names and descriptions in the user's language. Items are ordered by their last update.
+// @Tags wallets
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param from query string false "Start of the period (RFC3339 or YYYY-MM-DD), wallet creation by default"
+// @Param to query string false "End of the period (RFC3339 or YYYY-MM-DD, inclusive), now by default"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]ActivityItem,meta=response.Meta} "Activity items"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID or period"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallets/{festivalId}/activity [get]
+func (h *Handler) GetMyActivity(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	req := ActivityRequest{
+		Page:         page,
+		PerPage:      perPage,
+		Locale:       response.Locale(c),
+		ExchangeRate: h.exchangeRate,
+		CurrencyName: h.currencyName,
+	}
+	if req.From, err = parseStatementDate(c.Query("from"), false); err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid from date", nil)
+		return
+	}
+	if req.To, err = parseStatementDate(c.Query("to"), true); err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid to date", nil)
+		return
+	}
+
+	items, total, err := h.service.GetActivity(c.Request.Context(), userID, festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, items, &response.Meta{
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// GetMyStatement returns the statement of the current user's wallet
+// @Summary Get my wallet statement
+// @Description Render a branded statement of the user's wallet over a period, in the user's language. The PDF is returned as a download, or emailed to the account address with email=true.
+// @Tags wallets
+// @Produce application/pdf
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param format query string false "Statement format" Enums(pdf) default(pdf)
+// @Param from query string false "Start of the period (RFC3339 or YYYY-MM-DD), wallet creation by default"
+// @Param to query string false "End of the period (RFC3339 or YYYY-MM-DD, inclusive), now by default"
+// @Param email query bool false "Email the statement instead of downloading it"
+// @Success 200 {file} binary "Statement PDF"
+// @Success 202 {object} response.Response "Statement emailed"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID, format or period"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallets/{festivalId}/statement [get]
+func (h *Handler) GetMyStatement(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	if format := c.DefaultQuery("format", "pdf"); format != "pdf" {
+		response.BadRequest(c, "UNSUPPORTED_FORMAT", "Only the pdf format is supported", nil)
+		return
+	}
+
+	req := StatementRequest{
+		HolderEmail: c.GetString("email"),
+		Locale:      response.Locale(c),
+		Email:       c.Query("email") == "true",
+	}
+	if req.From, err = parseStatementDate(c.Query("from"), false); err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid from date", nil)
+		return
+	}
+	if req.To, err = parseStatementDate(c.Query("to"), true); err != nil {
+		response.BadRequest(c, "INVALID_DATE", "Invalid to date", nil)
+		return
+	}
+	if req.Email && req.HolderEmail == "" {
+		response.BadRequest(c, "NO_EMAIL", "Your account has no email address", nil)
+		return
+	}
+
+	statement, err := h.service.GetStatement(c.Request.Context(), userID, festivalID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if req.Email {
+		response.Accepted(c, gin.H{"emailedTo": req.HolderEmail})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+statement.Filename)
+	c.Data(http.StatusOK, "application/pdf", statement.PDF)
+}
+
+// UpdateMyActivityPush changes how the user is told about the activity of their wallet
+// @Summary Set wallet activity notifications
+// @Description Choose between a push notification per purchase and top-up (INSTANT, the default), a nightly digest of the day's spending with the top stands and remaining balance (DIGEST), or no wallet activity notifications (OFF). Digests are sent at 22:00 festival time, or when the quiet hours of the user end if they cover that time.
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body UpdateActivityPushRequest true "Notification mode"
+// @Success 200 {object} response.Response{data=WalletResponse} "Wallet with its notification mode"
+// @Failure 400 {object} response.ErrorResponse "Invalid festival ID or mode"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallets/{festivalId}/activity-push [put]
+func (h *Handler) UpdateMyActivityPush(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req UpdateActivityPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	wallet, err := h.service.SetActivityPush(c.Request.Context(), userID, festivalID, req.Mode)
+	if err != nil {
+		if errors.Is(err, ErrInvalidActivityPush) {
+			response.BadRequest(c, "INVALID_MODE", err.Error(), nil)
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, wallet.ToResponse(h.exchangeRate, h.currencyName))
+}
+
 // GetWallet returns a wallet by ID (staff only)
 // @Summary Get wallet by ID
 // @Description Get wallet details by ID (staff only)
@@ -299,7 +617,7 @@ func (h *Handler) GetWallet(c *gin.Context) {
 // @Param id path string true "Wallet ID" format(uuid)
 // @Param request body TopUpRequest true "Top up data"
 // @Success 200 {object} response.Response{data=TransactionResponse} "Transaction details"
-// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or wallet frozen"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
@@ -313,7 +631,7 @@ func (h *Handler) TopUp(c *gin.Context) {
 
 	var req TopUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -321,6 +639,10 @@ func (h *Handler) TopUp(c *gin.Context) {
 
 	tx, err := h.service.TopUp(c.Request.Context(), id, req, staffID)
 	if err != nil {
+		if errors.Is(err, ErrWalletFrozen) {
+			response.BadRequest(c, "WALLET_FROZEN", "Wallet is frozen", nil)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -344,7 +666,7 @@ func (h *Handler) TopUp(c *gin.Context) {
 func (h *Handler) ProcessPayment(c *gin.Context) {
 	var req PaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -360,7 +682,7 @@ func (h *Handler) ProcessPayment(c *gin.Context) {
 			response.BadRequest(c, "INSUFFICIENT_BALANCE", "Insufficient balance", nil)
 			return
 		}
-		if err.Error() == "wallet is not active" {
+		if errors.Is(err, ErrWalletFrozen) || err.Error() == "wallet is not active" {
 			response.BadRequest(c, "WALLET_FROZEN", "Wallet is frozen", nil)
 			return
 		}
@@ -389,13 +711,22 @@ func (h *Handler) ValidateQR(c *gin.Context) {
 		QRCode string `json:"qrCode" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
 	payload, err := h.service.ValidateQRPayload(c.Request.Context(), req.QRCode)
 	if err != nil {
-		response.BadRequest(c, "INVALID_QR", err.Error(), nil)
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			response.NotFound(c, "Wallet not found")
+		case errors.Is(err, ErrQRCodeRevoked):
+			response.BadRequest(c, "QR_REVOKED", err.Error(), nil)
+		case errors.Is(err, ErrQRWalletNotActive):
+			response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+		default:
+			response.BadRequest(c, "INVALID_QR", err.Error(), nil)
+		}
 		return
 	}
 
@@ -430,7 +761,7 @@ func (h *Handler) ValidateQR(c *gin.Context) {
 func (h *Handler) RefundPayment(c *gin.Context) {
 	var req RefundRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		response.ValidationFailed(c, err)
 		return
 	}
 
@@ -451,14 +782,17 @@ func (h *Handler) RefundPayment(c *gin.Context) {
 
 // FreezeWallet freezes a wallet (admin only)
 // @Summary Freeze wallet
-// @Description Freeze a wallet to prevent transactions (admin only)
+// @Description Freeze a wallet, e.g. after a lost wristband or a suspected fraud. Payments, top-ups and offline transactions are refused, POS devices block its QR codes at their next offline spec refresh, and the holder is notified by email. The wallet is unfrozen automatically at until, or thawAfterHours from now, when given. Freezing a frozen wallet again replaces its reason and schedule (admin only)
 // @Tags wallets
+// @Accept json
 // @Produce json
 // @Param id path string true "Wallet ID" format(uuid)
+// @Param request body FreezeWalletRequest true "Reason and automatic unfreeze"
 // @Success 200 {object} response.Response{data=WalletResponse} "Frozen wallet"
-// @Failure 400 {object} response.ErrorResponse "Invalid wallet ID"
+// @Failure 400 {object} response.ErrorResponse "Invalid wallet ID or automatic unfreeze"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 409 {object} response.ErrorResponse "Wallet closed"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /wallets/{id}/freeze [post]
@@ -469,13 +803,24 @@ func (h *Handler) FreezeWallet(c *gin.Context) {
 		return
 	}
 
-	wallet, err := h.service.FreezeWallet(c.Request.Context(), id)
+	var req FreezeWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	wallet, err := h.service.FreezeWallet(c.Request.Context(), id, req, getStaffID(c))
 	if err != nil {
-		if err == errors.ErrNotFound {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
 			response.NotFound(c, "Wallet not found")
-			return
+		case errors.Is(err, ErrFreezeThawConflict), errors.Is(err, ErrFreezeUntilInvalid):
+			response.BadRequest(c, "INVALID_THAW", err.Error(), nil)
+		case errors.Is(err, ErrFreezeClosedWallet):
+			response.Conflict(c, "WALLET_CLOSED", err.Error())
+		default:
+			response.InternalError(c, err.Error())
 		}
-		response.InternalError(c, err.Error())
 		return
 	}
 
@@ -484,7 +829,7 @@ func (h *Handler) FreezeWallet(c *gin.Context) {
 
 // UnfreezeWallet unfreezes a wallet (admin only)
 // @Summary Unfreeze wallet
-// @Description Unfreeze a previously frozen wallet (admin only)
+// @Description Unfreeze a frozen wallet before its automatic unfreeze, if any, and notify the holder by email (admin only)
 // @Tags wallets
 // @Produce json
 // @Param id path string true "Wallet ID" format(uuid)
@@ -492,6 +837,7 @@ func (h *Handler) FreezeWallet(c *gin.Context) {
 // @Failure 400 {object} response.ErrorResponse "Invalid wallet ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 409 {object} response.ErrorResponse "Wallet not frozen"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /wallets/{id}/unfreeze [post]
@@ -504,7 +850,132 @@ func (h *Handler) UnfreezeWallet(c *gin.Context) {
 
 	wallet, err := h.service.UnfreezeWallet(c.Request.Context(), id)
 	if err != nil {
-		if err == errors.ErrNotFound {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			response.NotFound(c, "Wallet not found")
+		case errors.Is(err, ErrWalletNotFrozen):
+			response.Conflict(c, "WALLET_NOT_FROZEN", err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, wallet.ToResponse(h.exchangeRate, h.currencyName))
+}
+
+// ClaimWallet attaches an anonymous wallet to the current user
+// @Summary Claim anonymous wallet
+// @Description Attach the wallet of the claim code printed on a wristband card to the authenticated user, which makes it visible in the app and enables self-service refunds. If the user already has a wallet for the festival, the anonymous wallet is merged into it. Claiming a wallet again returns it
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param request body ClaimWalletRequest true "Claim code"
+// @Success 200 {object} response.Response{data=WalletResponse} "Claimed wallet"
+// @Failure 400 {object} response.ErrorResponse "Invalid claim code"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Wallet already claimed by another user"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallets/claim [post]
+func (h *Handler) ClaimWallet(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req ClaimWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	wallet, err := h.service.ClaimWallet(c.Request.Context(), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, wallet.ToResponse(h.exchangeRate, h.currencyName))
+}
+
+// ReplaceWristband replaces the wristband of a wallet, keeping its balance (staff only)
+// @Summary Replace wristband
+// @Description Replace a lost, stolen or damaged wristband by a new one bound to the same wallet, keeping its balance and history. The old wristbands, every one or only oldUid, are revoked and pushed to the POS devices of the festival right away, the QR material of the wallet is rotated and a freeze for the lost or stolen wristband is lifted. An anonymous wallet gets a new claim code, returned once. The replacement is audited with the staff identity (staff only)
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param id path string true "Wallet ID" format(uuid)
+// @Param request body ReplaceWristbandRequest true "New wristband and reason"
+// @Success 200 {object} response.Response{data=WristbandReplacementResponse} "Replacement, wallet and new claim code"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or old wristband not on the wallet"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 409 {object} response.ErrorResponse "Wallet closed or new wristband in use"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallets/{id}/replace-wristband [post]
+func (h *Handler) ReplaceWristband(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid wallet ID", nil)
+		return
+	}
+
+	var req ReplaceWristbandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	replacement, wallet, claimCode, err := h.service.ReplaceWristband(c.Request.Context(), id, req, getStaffID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			response.NotFound(c, "Wallet not found")
+		case errors.Is(err, ErrWristbandSameUID), errors.Is(err, ErrWristbandNotLinked):
+			response.BadRequest(c, "INVALID_WRISTBAND", err.Error(), nil)
+		case errors.Is(err, ErrReplaceClosedWallet):
+			response.Conflict(c, "WALLET_CLOSED", err.Error())
+		case errors.Is(err, ErrWristbandInUse), errors.Is(err, ErrWristbandOtherFestival):
+			response.Conflict(c, "WRISTBAND_IN_USE", err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.OK(c, WristbandReplacementResponse{
+		Replacement: *replacement,
+		Wallet:      wallet.ToResponse(h.exchangeRate, h.currencyName),
+		ClaimCode:   claimCode,
+	})
+}
+
+// GetWristbandReplacements returns the wristband replacements of a wallet (staff only)
+// @Summary Get wristband replacements
+// @Description Get the wristband replacements of a wallet, latest first, with the staff who made them (staff only)
+// @Tags wallets
+// @Produce json
+// @Param id path string true "Wallet ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]WristbandReplacement} "Wristband replacements"
+// @Failure 400 {object} response.ErrorResponse "Invalid wallet ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallets/{id}/wristband-replacements [get]
+func (h *Handler) GetWristbandReplacements(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid wallet ID", nil)
+		return
+	}
+
+	replacements, err := h.service.GetWristbandReplacements(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
 			response.NotFound(c, "Wallet not found")
 			return
 		}
@@ -512,11 +983,315 @@ func (h *Handler) UnfreezeWallet(c *gin.Context) {
 		return
 	}
 
-	response.OK(c, wallet.ToResponse(h.exchangeRate, h.currencyName))
+	response.OK(c, replacements)
+}
+
+// CreateAnonymousWallet sells a wallet without a user account (staff only)
+// @Summary Create anonymous wallet
+// @Description Create a wallet without a user account at the entrance, funded with cash (staff only). The response contains the claim code to print on the wristband card; it is returned only once
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param request body CreateAnonymousWalletRequest true "Festival and cash amount"
+// @Success 201 {object} response.Response{data=AnonymousWalletResponse} "Wallet, claim code and cash-in transaction"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallets/anonymous [post]
+func (h *Handler) CreateAnonymousWallet(c *gin.Context) {
+	var req CreateAnonymousWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	wallet, tx, claimCode, err := h.service.CreateAnonymousWallet(c.Request.Context(), req, getStaffID(c))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, AnonymousWalletResponse{
+		Wallet:      wallet.ToResponse(h.exchangeRate, h.currencyName),
+		ClaimCode:   claimCode,
+		Transaction: tx.ToResponse(h.exchangeRate, h.currencyName),
+	})
+}
+
+// GetMyMerges returns the merges of the current user's wallets
+// @Summary Get user's wallet merges
+// @Description Get the pending, completed and cancelled merges into or out of the authenticated user's wallets
+// @Tags wallets
+// @Produce json
+// @Success 200 {object} response.Response{data=[]WalletMerge} "List of wallet merges"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallet-merges [get]
+func (h *Handler) GetMyMerges(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	merges, err := h.service.GetUserMerges(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OK(c, merges)
+}
+
+// ConfirmMyMerge confirms a merge into the current user's wallet
+// @Summary Confirm wallet merge
+// @Description Move the balance, transaction history and wristbands of the source wallet into the authenticated user's wallet. Confirming a completed merge returns it unchanged
+// @Tags wallets
+// @Produce json
+// @Param id path string true "Merge ID" format(uuid)
+// @Success 200 {object} response.Response{data=WalletMerge} "Completed merge"
+// @Failure 400 {object} response.ErrorResponse "Wallets cannot be merged"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Merge is not into the user's wallet"
+// @Failure 404 {object} response.ErrorResponse "Merge not found"
+// @Failure 409 {object} response.ErrorResponse "Merge was cancelled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallet-merges/{id}/confirm [post]
+func (h *Handler) ConfirmMyMerge(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid merge ID", nil)
+		return
+	}
+
+	merge, err := h.service.ConfirmMerge(c.Request.Context(), id, &userID, &userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, merge)
+}
+
+// CancelMyMerge cancels a pending merge of the current user's wallets
+// @Summary Cancel wallet merge
+// @Description Cancel a pending merge into or out of the authenticated user's wallets
+// @Tags wallets
+// @Produce json
+// @Param id path string true "Merge ID" format(uuid)
+// @Success 200 {object} response.Response{data=WalletMerge} "Cancelled merge"
+// @Failure 400 {object} response.ErrorResponse "Invalid merge ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Merge not found"
+// @Failure 409 {object} response.ErrorResponse "Merge already completed"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /me/wallet-merges/{id}/cancel [post]
+func (h *Handler) CancelMyMerge(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid merge ID", nil)
+		return
+	}
+
+	merge, err := h.service.CancelMerge(c.Request.Context(), id, &userID, &userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, merge)
+}
+
+// InitiateMerge opens the merge of a source wallet into a target wallet (staff only)
+// @Summary Initiate wallet merge
+// @Description Open the merge of a wallet, e.g. one created with a wristband, into another wallet of the same festival (staff only). The merge completes when the target wallet's owner or staff confirm it; initiating the same merge again returns it
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param request body InitiateMergeRequest true "Wallets to merge"
+// @Success 201 {object} response.Response{data=WalletMerge} "Pending merge"
+// @Failure 400 {object} response.ErrorResponse "Wallets cannot be merged"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 409 {object} response.ErrorResponse "Source wallet already merged or has a pending merge"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallet-merges [post]
+func (h *Handler) InitiateMerge(c *gin.Context) {
+	var req InitiateMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	merge, err := h.service.InitiateMerge(c.Request.Context(), req, getStaffID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, merge)
+}
+
+// GetMerge returns a wallet merge by ID (staff only)
+// @Summary Get wallet merge
+// @Description Get a wallet merge by ID (staff only)
+// @Tags wallets
+// @Produce json
+// @Param id path string true "Merge ID" format(uuid)
+// @Success 200 {object} response.Response{data=WalletMerge} "Wallet merge"
+// @Failure 400 {object} response.ErrorResponse "Invalid merge ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Merge not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallet-merges/{id} [get]
+func (h *Handler) GetMerge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid merge ID", nil)
+		return
+	}
+
+	merge, err := h.service.GetMerge(c.Request.Context(), id, nil)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, merge)
+}
+
+// ConfirmMerge confirms a wallet merge on behalf of the attendee (staff only)
+// @Summary Confirm wallet merge (staff)
+// @Description Complete a pending merge on behalf of the attendee (staff only). Confirming a completed merge returns it unchanged
+// @Tags wallets
+// @Produce json
+// @Param id path string true "Merge ID" format(uuid)
+// @Success 200 {object} response.Response{data=WalletMerge} "Completed merge"
+// @Failure 400 {object} response.ErrorResponse "Wallets cannot be merged"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Merge not found"
+// @Failure 409 {object} response.ErrorResponse "Merge was cancelled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallet-merges/{id}/confirm [post]
+func (h *Handler) ConfirmMerge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid merge ID", nil)
+		return
+	}
+
+	merge, err := h.service.ConfirmMerge(c.Request.Context(), id, getStaffID(c), nil)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, merge)
+}
+
+// CancelMerge cancels a pending wallet merge (staff only)
+// @Summary Cancel wallet merge (staff)
+// @Description Cancel a pending wallet merge (staff only)
+// @Tags wallets
+// @Produce json
+// @Param id path string true "Merge ID" format(uuid)
+// @Success 200 {object} response.Response{data=WalletMerge} "Cancelled merge"
+// @Failure 400 {object} response.ErrorResponse "Invalid merge ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Merge not found"
+// @Failure 409 {object} response.ErrorResponse "Merge already completed"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /wallet-merges/{id}/cancel [post]
+func (h *Handler) CancelMerge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid merge ID", nil)
+		return
+	}
+
+	merge, err := h.service.CancelMerge(c.Request.Context(), id, getStaffID(c), nil)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, merge)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errors.ErrNotFound):
+		response.NotFound(c, "Wallet or merge not found")
+	case errors.Is(err, errors.ErrForbidden):
+		response.Forbidden(c, "Merge is not into your wallet")
+	case errors.Is(err, ErrMergeSameWallet):
+		response.BadRequest(c, "MERGE_SAME_WALLET", err.Error(), nil)
+	case errors.Is(err, ErrMergeFestivalMismatch):
+		response.BadRequest(c, "MERGE_FESTIVAL_MISMATCH", err.Error(), nil)
+	case errors.Is(err, ErrMergeWalletNotActive):
+		response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+	case errors.Is(err, ErrMergeTargetAnonymous):
+		response.BadRequest(c, "WALLET_ANONYMOUS", err.Error(), nil)
+	case errors.Is(err, ErrQRWalletNotActive):
+		response.BadRequest(c, "WALLET_NOT_ACTIVE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidClaimCode):
+		response.BadRequest(c, "INVALID_CLAIM_CODE", "Invalid claim code", nil)
+	case errors.Is(err, ErrWalletAlreadyClaimed):
+		response.Conflict(c, "WALLET_ALREADY_CLAIMED", err.Error())
+	case errors.Is(err, ErrWalletAlreadyMerged):
+		response.Conflict(c, "WALLET_ALREADY_MERGED", err.Error())
+	case errors.Is(err, ErrMergeAlreadyPending):
+		response.Conflict(c, "MERGE_PENDING", err.Error())
+	case errors.Is(err, ErrMergeNotPending):
+		response.Conflict(c, "MERGE_NOT_PENDING", err.Error())
+	case errors.Is(err, ErrStatementPeriod), errors.Is(err, ErrActivityPeriod):
+		response.BadRequest(c, "INVALID_PERIOD", err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
 }
 
 // Helper functions
 
+// parseStatementDate parses an optional RFC3339 timestamp or YYYY-MM-DD date; a date used
+// as the end of a period includes the whole day
+func parseStatementDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
 func getUserID(c *gin.Context) (uuid.UUID, error) {
 	userIDStr := c.GetString("user_id")
 	if userIDStr == "" {
@@ -0,0 +1,107 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/posdevice"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SignedDebits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redisServer := miniredis.RunT(t)
+	keyring := security.NewKeyring("test-request-signing-secret", nil, 0)
+	deviceID := uuid.New().String()
+	staffID := uuid.New()
+	walletID := uuid.New()
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("ProcessPayment", mock.Anything, walletID, int64(450), mock.AnythingOfType("*wallet.Transaction")).Return(nil).Once()
+
+	cfg := posdevice.DefaultSignedRequestConfig(keyring, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), posdevice.DeviceIDKey)
+	cfg.Required = true
+
+	router := gin.New()
+	staff := router.Group("", func(c *gin.Context) {
+		// As set by the staff authentication and posdevice.IdentifyDevice
+		c.Set("user_id", staffID.String())
+		c.Set(posdevice.DeviceIDKey, deviceID)
+		c.Next()
+	})
+	NewHandler(NewService(mockRepo, testSecretKey)).RegisterRoutes(staff, posdevice.SignedRequests(cfg))
+
+	payment := []byte(fmt.Sprintf(`{"walletId":%q,"amount":450,"standId":%q}`, walletID, uuid.New()))
+	sign := func(path string, body []byte, timestamp int64, nonce string) http.Header {
+		key := security.RequestSigningKey(keyring.Current(), deviceID)
+		header := http.Header{}
+		header.Set(security.SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+		header.Set(security.SignatureNonceHeader, nonce)
+		header.Set(security.SignatureHeader, security.SignRequest(key, http.MethodPost, path, timestamp, nonce, body))
+		return header
+	}
+	post := func(path string, body []byte, header http.Header) (int, string) {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var errResp response.ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &errResp)
+		return w.Code, errResp.Error.Code
+	}
+
+	t.Run("unsigned debits are rejected", func(t *testing.T) {
+		for _, path := range []string{"/payments", "/payments/refund", "/wallets/" + walletID.String() + "/topup"} {
+			status, code := post(path, payment, nil)
+			assert.Equal(t, http.StatusUnauthorized, status, path)
+			assert.Equal(t, "SIGNATURE_REQUIRED", code, path)
+		}
+	})
+
+	t.Run("stale timestamp is rejected", func(t *testing.T) {
+		header := sign("/payments", payment, time.Now().Add(-10*time.Minute).Unix(), "stale-nonce-0123456789")
+		status, code := post("/payments", payment, header)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.Equal(t, "SIGNATURE_EXPIRED", code)
+	})
+
+	t.Run("tampered body is rejected", func(t *testing.T) {
+		header := sign("/payments", payment, time.Now().Unix(), "tampered-nonce-0123456789")
+		tampered := bytes.Replace(payment, []byte(`"amount":450`), []byte(`"amount":4500`), 1)
+		status, code := post("/payments", tampered, header)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.Equal(t, "SIGNATURE_INVALID", code)
+	})
+
+	t.Run("signed debit is processed once", func(t *testing.T) {
+		header := sign("/payments", payment, time.Now().Unix(), "payment-nonce-0123456789")
+		status, _ := post("/payments", payment, header)
+		assert.Equal(t, http.StatusOK, status)
+
+		// The same request captured and sent again does not debit the wallet twice
+		status, code := post("/payments", payment, header)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "REQUEST_REPLAYED", code)
+	})
+
+	require.True(t, mockRepo.AssertExpectations(t))
+	mockRepo.AssertNumberOfCalls(t, "ProcessPayment", 1)
+}
@@ -1,27 +1,52 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-// Wallet represents a user's wallet for a specific festival
+// Wallet represents a user's wallet for a specific festival. Anonymous wallets are
+// created at the entrance without a user and get one when claimed.
 type Wallet struct {
-	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID     uuid.UUID    `json:"userId" gorm:"type:uuid;not null;index"`
-	FestivalID uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
-	Balance    int64        `json:"balance" gorm:"default:0"` // Balance in cents (smallest currency unit)
-	Status     WalletStatus `json:"status" gorm:"default:'ACTIVE'"`
-	CreatedAt  time.Time    `json:"createdAt"`
-	UpdatedAt  time.Time    `json:"updatedAt"`
+	ID            uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        *uuid.UUID       `json:"userId,omitempty" gorm:"type:uuid;index"` // Nil until an anonymous wallet is claimed
+	FestivalID    uuid.UUID        `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Balance       int64            `json:"balance" gorm:"default:0"` // Balance in cents (smallest currency unit)
+	Status        WalletStatus     `json:"status" gorm:"default:'ACTIVE'"`
+	MergedIntoID  *uuid.UUID       `json:"mergedIntoId,omitempty" gorm:"type:uuid;index"` // Wallet that absorbed this one in a merge
+	ClaimCodeHash *string          `json:"-" gorm:"uniqueIndex"`                          // HMAC of the claim code printed on the wristband card
+	ClaimedAt     *time.Time       `json:"claimedAt,omitempty"`
+	QRGeneration  int              `json:"-" gorm:"not null;default:0"` // Bumped to revoke the QR material of the wallet
+	FreezeReason  FreezeReason     `json:"freezeReason,omitempty"`      // Set while frozen
+	FreezeNote    string           `json:"freezeNote,omitempty"`
+	FrozenAt      *time.Time       `json:"frozenAt,omitempty"`
+	FrozenBy      *uuid.UUID       `json:"frozenBy,omitempty" gorm:"type:uuid"`
+	FrozenUntil   *time.Time       `json:"frozenUntil,omitempty"`                                       // Unfrozen automatically at this time; nil to stay frozen
+	ImportID      *uuid.UUID       `json:"importId,omitempty" gorm:"column:legacy_import_id;type:uuid"` // Legacy import that brought the wallet from a previous provider
+	ActivityPush  ActivityPushMode `json:"activityPush" gorm:"default:'INSTANT'"`                       // How the holder is told about purchases and top-ups
+	LastDigestAt  *time.Time       `json:"-" gorm:"column:last_activity_digest_at"`                     // End of the period of the last activity digest sent
+	CreatedAt     time.Time        `json:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
 }
 
 func (Wallet) TableName() string {
 	return "wallets"
 }
 
+// IsAnonymous reports whether the wallet has not been claimed by a user yet
+func (w *Wallet) IsAnonymous() bool {
+	return w.UserID == nil
+}
+
+// IsOwnedBy reports whether the wallet belongs to the user
+func (w *Wallet) IsOwnedBy(userID uuid.UUID) bool {
+	return w.UserID != nil && *w.UserID == userID
+}
+
 type WalletStatus string
 
 const (
@@ -30,6 +55,36 @@ const (
 	WalletStatusClosed   WalletStatus = "CLOSED"
 )
 
+// FreezeReason tells why a wallet was frozen
+type FreezeReason string
+
+const (
+	FreezeReasonLostWristband  FreezeReason = "LOST_WRISTBAND"
+	FreezeReasonStolen         FreezeReason = "STOLEN"
+	FreezeReasonSuspectedFraud FreezeReason = "SUSPECTED_FRAUD"
+	FreezeReasonChargeback     FreezeReason = "CHARGEBACK"
+	FreezeReasonHolderRequest  FreezeReason = "HOLDER_REQUEST" // Asked by the holder, e.g. from the app
+	FreezeReasonOther          FreezeReason = "OTHER"
+)
+
+// ActivityPushMode tells how the holder of a wallet is told about its purchases and
+// top-ups
+type ActivityPushMode string
+
+const (
+	ActivityPushInstant ActivityPushMode = "INSTANT" // A push notification per transaction
+	ActivityPushDigest  ActivityPushMode = "DIGEST"  // A nightly summary of the day's spending
+	ActivityPushOff     ActivityPushMode = "OFF"
+)
+
+// IsValid checks if the mode is known
+func (m ActivityPushMode) IsValid() bool {
+	return m == ActivityPushInstant || m == ActivityPushDigest || m == ActivityPushOff
+}
+
+// MaxFreezeDuration bounds how far ahead an automatic unfreeze can be scheduled
+const MaxFreezeDuration = 90 * 24 * time.Hour
+
 // Transaction represents a wallet transaction
 type Transaction struct {
 	ID            uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -43,6 +98,8 @@ type Transaction struct {
 	StaffID       *uuid.UUID        `json:"staffId,omitempty" gorm:"type:uuid"` // Staff who processed the transaction
 	Metadata      TransactionMeta   `json:"metadata" gorm:"type:jsonb;default:'{}'"`
 	Status        TransactionStatus `json:"status" gorm:"default:'COMPLETED'"`
+	ImportID      *uuid.UUID        `json:"importId,omitempty" gorm:"column:legacy_import_id;type:uuid"` // Legacy import that brought the transaction from a previous provider
+	RoundUp       *RoundUp          `json:"roundUp,omitempty" gorm:"-"`                                  // Donation a purchase was just rounded up by
 	CreatedAt     time.Time         `json:"createdAt"`
 }
 
@@ -53,12 +110,15 @@ func (Transaction) TableName() string {
 type TransactionType string
 
 const (
-	TransactionTypeTopUp    TransactionType = "TOP_UP"    // Online top-up
-	TransactionTypeCashIn   TransactionType = "CASH_IN"   // Cash top-up at booth
-	TransactionTypePurchase TransactionType = "PURCHASE"  // Payment at stand
-	TransactionTypeRefund   TransactionType = "REFUND"    // Refund from stand/admin
-	TransactionTypeTransfer TransactionType = "TRANSFER"  // P2P transfer
-	TransactionTypeCashOut  TransactionType = "CASH_OUT"  // Withdrawal/refund at end
+	TransactionTypeTopUp      TransactionType = "TOP_UP"     // Online top-up
+	TransactionTypeCashIn     TransactionType = "CASH_IN"    // Cash top-up at booth
+	TransactionTypePurchase   TransactionType = "PURCHASE"   // Payment at stand
+	TransactionTypeRefund     TransactionType = "REFUND"     // Refund from stand/admin
+	TransactionTypeTransfer   TransactionType = "TRANSFER"   // P2P transfer
+	TransactionTypeCashOut    TransactionType = "CASH_OUT"   // Withdrawal/refund at end
+	TransactionTypeMerge      TransactionType = "MERGE"      // Balance moved by a wallet merge
+	TransactionTypeAdjustment TransactionType = "ADJUSTMENT" // Credit or debit by the organizer, e.g. a compensation
+	TransactionTypeDonation   TransactionType = "DONATION"   // Round-up of a purchase for the festival's charity
 )
 
 type TransactionStatus string
@@ -68,6 +128,7 @@ const (
 	TransactionStatusCompleted TransactionStatus = "COMPLETED"
 	TransactionStatusFailed    TransactionStatus = "FAILED"
 	TransactionStatusRefunded  TransactionStatus = "REFUNDED"
+	TransactionStatusImported  TransactionStatus = "IMPORTED" // History of a previous provider; never moved a balance here
 )
 
 type TransactionMeta struct {
@@ -85,6 +146,16 @@ type TopUpRequest struct {
 	Reference     string `json:"reference,omitempty"`
 }
 
+// AdjustRequest credits (positive amount) or debits (negative amount) a wallet on
+// behalf of the organizer. The transaction ID is chosen by the caller, so that an
+// adjustment retried after a failure is applied once.
+type AdjustRequest struct {
+	TransactionID uuid.UUID
+	Amount        int64
+	Reason        string
+	Reference     string
+}
+
 // PaymentRequest represents a payment request from a stand
 type PaymentRequest struct {
 	WalletID   uuid.UUID `json:"walletId" binding:"required"`
@@ -99,16 +170,58 @@ type RefundRequest struct {
 	Reason        string    `json:"reason"`
 }
 
+// CreateAnonymousWalletRequest represents a request to sell a wallet without a user
+// account at the entrance, funded with cash
+type CreateAnonymousWalletRequest struct {
+	FestivalID uuid.UUID `json:"festivalId" binding:"required"`
+	Amount     int64     `json:"amount" binding:"required,min=100"` // Cash paid, minimum 1€ = 100 cents
+}
+
+// ClaimWalletRequest represents a request to attach an anonymous wallet to the current user
+type ClaimWalletRequest struct {
+	ClaimCode string `json:"claimCode" binding:"required,min=12,max=20"`
+}
+
+// FreezeWalletRequest represents a request to freeze a wallet. The wallet is unfrozen
+// automatically at Until, or ThawAfterHours from now, when given.
+type FreezeWalletRequest struct {
+	Reason         FreezeReason `json:"reason" binding:"required,oneof=LOST_WRISTBAND STOLEN SUSPECTED_FRAUD CHARGEBACK HOLDER_REQUEST OTHER"`
+	Note           string       `json:"note,omitempty" binding:"max=500"`
+	Until          *time.Time   `json:"until,omitempty"`
+	ThawAfterHours int          `json:"thawAfterHours,omitempty" binding:"omitempty,min=1,max=2160"`
+}
+
+// UpdateActivityPushRequest represents a request of a holder to change how they are told
+// about the activity of their wallet
+type UpdateActivityPushRequest struct {
+	Mode ActivityPushMode `json:"mode" binding:"required,oneof=INSTANT DIGEST OFF"`
+}
+
+// ReplaceWristbandRequest represents a request to replace the wristband of a wallet.
+// Every wristband of the wallet is revoked, or only OldUID when given.
+type ReplaceWristbandRequest struct {
+	NewUID string            `json:"newUid" binding:"required,min=4,max=64"`
+	OldUID string            `json:"oldUid,omitempty" binding:"omitempty,min=4,max=64"`
+	Reason ReplacementReason `json:"reason" binding:"required,oneof=LOST STOLEN DAMAGED OTHER"`
+	Note   string            `json:"note,omitempty" binding:"max=500"`
+}
+
 // WalletResponse represents the API response for a wallet
 type WalletResponse struct {
-	ID              uuid.UUID    `json:"id"`
-	UserID          uuid.UUID    `json:"userId"`
-	FestivalID      uuid.UUID    `json:"festivalId"`
-	Balance         int64        `json:"balance"`
-	BalanceDisplay  string       `json:"balanceDisplay"` // Formatted balance for display
-	Status          WalletStatus `json:"status"`
-	CreatedAt       string       `json:"createdAt"`
-	UpdatedAt       string       `json:"updatedAt"`
+	ID             uuid.UUID        `json:"id"`
+	UserID         *uuid.UUID       `json:"userId,omitempty"`
+	FestivalID     uuid.UUID        `json:"festivalId"`
+	Balance        int64            `json:"balance"`
+	BalanceDisplay string           `json:"balanceDisplay"` // Formatted balance for display
+	Status         WalletStatus     `json:"status"`
+	Anonymous      bool             `json:"anonymous"`
+	MergedIntoID   *uuid.UUID       `json:"mergedIntoId,omitempty"`
+	ClaimedAt      *time.Time       `json:"claimedAt,omitempty"`
+	FreezeReason   FreezeReason     `json:"freezeReason,omitempty"`
+	FrozenUntil    *time.Time       `json:"frozenUntil,omitempty"`
+	ActivityPush   ActivityPushMode `json:"activityPush"`
+	CreatedAt      string           `json:"createdAt"`
+	UpdatedAt      string           `json:"updatedAt"`
 }
 
 func (w *Wallet) ToResponse(exchangeRate float64, currencyName string) WalletResponse {
@@ -123,11 +236,25 @@ func (w *Wallet) ToResponse(exchangeRate float64, currencyName string) WalletRes
 		Balance:        w.Balance,
 		BalanceDisplay: balanceDisplay,
 		Status:         w.Status,
+		Anonymous:      w.IsAnonymous(),
+		MergedIntoID:   w.MergedIntoID,
+		ClaimedAt:      w.ClaimedAt,
+		FreezeReason:   w.FreezeReason,
+		FrozenUntil:    w.FrozenUntil,
+		ActivityPush:   w.ActivityPush,
 		CreatedAt:      w.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:      w.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
+// AnonymousWalletResponse is returned once when an anonymous wallet is created; the
+// claim code is printed on the wristband card and cannot be retrieved later
+type AnonymousWalletResponse struct {
+	Wallet      WalletResponse      `json:"wallet"`
+	ClaimCode   string              `json:"claimCode"`
+	Transaction TransactionResponse `json:"transaction"`
+}
+
 // TransactionResponse represents the API response for a transaction
 type TransactionResponse struct {
 	ID            uuid.UUID         `json:"id"`
@@ -142,6 +269,7 @@ type TransactionResponse struct {
 	StaffID       *uuid.UUID        `json:"staffId,omitempty"`
 	Metadata      TransactionMeta   `json:"metadata"`
 	Status        TransactionStatus `json:"status"`
+	RoundUp       *RoundUp          `json:"roundUp,omitempty"` // Printed on the receipt of a purchase rounded up
 	CreatedAt     string            `json:"createdAt"`
 }
 
@@ -162,10 +290,160 @@ func (t *Transaction) ToResponse(exchangeRate float64, currencyName string) Tran
 		StaffID:       t.StaffID,
 		Metadata:      t.Metadata,
 		Status:        t.Status,
+		RoundUp:       t.RoundUp,
 		CreatedAt:     t.CreatedAt.Format(time.RFC3339),
 	}
 }
 
+// Wallet merge errors
+var (
+	ErrMergeSameWallet       = errors.New("cannot merge a wallet into itself")
+	ErrMergeFestivalMismatch = errors.New("wallets belong to different festivals")
+	ErrMergeWalletNotActive  = errors.New("only active wallets can be merged")
+	ErrWalletAlreadyMerged   = errors.New("wallet was already merged into another wallet")
+	ErrMergeAlreadyPending   = errors.New("wallet already has a pending merge into another wallet")
+	ErrMergeNotPending       = errors.New("merge is no longer pending")
+	ErrMergeTargetAnonymous  = errors.New("target wallet must belong to a user")
+)
+
+// Wallet adjustment errors
+var (
+	ErrAdjustmentAmount       = errors.New("adjustment amount must not be zero")
+	ErrAdjustmentInsufficient = errors.New("insufficient balance for the debit")
+	ErrAdjustmentNotActive    = errors.New("wallet is not active")
+	ErrAdjustmentApplied      = errors.New("adjustment was already applied")
+)
+
+// Wallet freeze errors
+var (
+	ErrWalletFrozen       = errors.New("wallet is frozen")
+	ErrWalletNotFrozen    = errors.New("wallet is not frozen")
+	ErrFreezeClosedWallet = errors.New("closed wallets cannot be frozen")
+	ErrFreezeThawConflict = errors.New("give either until or thawAfterHours, not both")
+	ErrFreezeUntilInvalid = errors.New("automatic unfreeze must be in the future and within 90 days")
+)
+
+// Wristband replacement errors
+var (
+	ErrReplaceClosedWallet    = errors.New("wristbands of closed wallets cannot be replaced")
+	ErrWristbandSameUID       = errors.New("new wristband must differ from the old one")
+	ErrWristbandInUse         = errors.New("new wristband is already in use")
+	ErrWristbandOtherFestival = errors.New("new wristband belongs to another festival")
+	ErrWristbandNotLinked     = errors.New("old wristband is not linked to the wallet")
+)
+
+// Wallet claim errors
+var (
+	ErrInvalidClaimCode     = errors.New("invalid claim code")
+	ErrWalletAlreadyClaimed = errors.New("wallet was already claimed by another user")
+)
+
+// Wallet statement errors
+var (
+	ErrStatementUnavailable = errors.New("wallet statements are not available")
+	ErrStatementPeriod      = errors.New("statement period must start before it ends")
+)
+
+// Wallet activity errors
+var (
+	ErrActivityPeriod      = errors.New("activity period must start before it ends")
+	ErrInvalidActivityPush = errors.New("activity push mode must be INSTANT, DIGEST or OFF")
+)
+
+// Wallet QR code errors
+var (
+	ErrQRCodeExpired     = errors.New("QR code expired")
+	ErrQRCodeNotYetValid = errors.New("QR code not yet valid, check the device clock")
+	ErrQRCodeSignature   = errors.New("invalid QR code signature")
+	ErrQRCodeRevoked     = errors.New("QR code was revoked, the wallet app must fetch new QR material")
+	ErrQRWalletNotActive = errors.New("wallet is not active")
+)
+
+// WalletMerge records the consolidation of a source wallet into a target wallet
+// of the same festival, e.g. a wallet created with a wristband into the wallet of
+// the account the attendee later logged in with. Completed merges are kept as the
+// audit trail of the balance moved and the wristbands relinked.
+type WalletMerge struct {
+	ID                  uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID          uuid.UUID   `json:"festivalId" gorm:"type:uuid;not null;index"`
+	SourceWalletID      uuid.UUID   `json:"sourceWalletId" gorm:"type:uuid;not null;index"`
+	TargetWalletID      uuid.UUID   `json:"targetWalletId" gorm:"type:uuid;not null;index"`
+	SourceUserID        *uuid.UUID  `json:"sourceUserId,omitempty" gorm:"type:uuid"` // Nil for anonymous wallets
+	TargetUserID        uuid.UUID   `json:"targetUserId" gorm:"type:uuid;not null;index"`
+	Status              MergeStatus `json:"status" gorm:"default:'PENDING'"`
+	Amount              int64       `json:"amount"`              // Balance moved to the target wallet, in cents
+	TargetBalanceBefore int64       `json:"targetBalanceBefore"` // Target balance when the merge completed
+	TargetBalanceAfter  int64       `json:"targetBalanceAfter"`
+	TransactionCount    int64       `json:"transactionCount"` // Source transactions now shown in the target history
+	TagsRelinked        int64       `json:"tagsRelinked"`     // Wristbands moved to the target wallet
+	InitiatedBy         *uuid.UUID  `json:"initiatedBy,omitempty" gorm:"type:uuid"`
+	ConfirmedBy         *uuid.UUID  `json:"confirmedBy,omitempty" gorm:"type:uuid"`
+	CancelledBy         *uuid.UUID  `json:"cancelledBy,omitempty" gorm:"type:uuid"`
+	CreatedAt           time.Time   `json:"createdAt"`
+	ConfirmedAt         *time.Time  `json:"confirmedAt,omitempty"`
+	CancelledAt         *time.Time  `json:"cancelledAt,omitempty"`
+	UpdatedAt           time.Time   `json:"updatedAt"`
+}
+
+func (WalletMerge) TableName() string {
+	return "wallet_merges"
+}
+
+type MergeStatus string
+
+const (
+	MergeStatusPending   MergeStatus = "PENDING"   // Waiting for confirmation
+	MergeStatusCompleted MergeStatus = "COMPLETED" // Balance and wristbands moved
+	MergeStatusCancelled MergeStatus = "CANCELLED"
+)
+
+// InitiateMergeRequest represents a request to merge a source wallet into a target wallet
+type InitiateMergeRequest struct {
+	SourceWalletID uuid.UUID `json:"sourceWalletId" binding:"required"`
+	TargetWalletID uuid.UUID `json:"targetWalletId" binding:"required"`
+}
+
+// WristbandReplacement records the replacement of the wristbands of a wallet by a new
+// one, e.g. after a loss. The wallet keeps its balance and history; the old wristbands
+// are revoked and its QR generation bumped, so that neither works again anywhere.
+type WristbandReplacement struct {
+	ID                uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID        uuid.UUID         `json:"festivalId" gorm:"type:uuid;not null;index"`
+	WalletID          uuid.UUID         `json:"walletId" gorm:"type:uuid;not null;index"`
+	OldUIDs           pq.StringArray    `json:"oldUids" gorm:"column:old_uids;type:text[]"` // Wristbands revoked
+	NewUID            string            `json:"newUid" gorm:"column:new_uid;not null"`
+	Reason            ReplacementReason `json:"reason" gorm:"not null"`
+	Note              string            `json:"note,omitempty"`
+	QRGeneration      int               `json:"qrGeneration"`      // Codes of lower generations are refused
+	ClaimCodeReissued bool              `json:"claimCodeReissued"` // Anonymous wallet given a new claim code
+	Unfrozen          bool              `json:"unfrozen"`          // Freeze for the lost or stolen wristband lifted
+	ReplacedBy        *uuid.UUID        `json:"replacedBy,omitempty" gorm:"type:uuid"`
+	CreatedAt         time.Time         `json:"createdAt"`
+}
+
+func (WristbandReplacement) TableName() string {
+	return "wristband_replacements"
+}
+
+// ReplacementReason tells why a wristband was replaced
+type ReplacementReason string
+
+const (
+	ReplacementReasonLost    ReplacementReason = "LOST"
+	ReplacementReasonStolen  ReplacementReason = "STOLEN"
+	ReplacementReasonDamaged ReplacementReason = "DAMAGED"
+	ReplacementReasonOther   ReplacementReason = "OTHER"
+)
+
+// WristbandReplacementResponse is returned once when a wristband is replaced; the claim
+// code of an anonymous wallet is reissued, since the old one was printed on the card
+// handed out with the lost wristband
+type WristbandReplacementResponse struct {
+	Replacement WristbandReplacement `json:"replacement"`
+	Wallet      WalletResponse       `json:"wallet"`
+	ClaimCode   string               `json:"claimCode,omitempty"`
+}
+
 func formatTokens(tokens float64, currencyName string) string {
 	if tokens == float64(int64(tokens)) {
 		return fmt.Sprintf("%.0f %s", tokens, currencyName)
@@ -0,0 +1,310 @@
+package wallet
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+// Wallet QR codes rotate TOTP-style: a code is signed for one rotation step with a seed
+// derived from the wallet, and accepted only around that step. The wallet app can sign
+// codes itself with the seed of its QR material, and POS devices can verify them
+// offline with the festival keys of the offline spec. Rotating the material of a
+// wallet bumps its QR generation, which revokes every code signed with the old seed.
+const (
+	// DefaultQRPeriod is how long a wallet QR code is shown before the next one
+	DefaultQRPeriod = 30 * time.Second
+	// QRSkewSteps is the number of steps accepted either side of the current one, to
+	// allow for clock drift between phones and POS devices and for the time to scan
+	QRSkewSteps = 1
+	// QRMaterialRefresh is how often the wallet app should fetch its QR material when
+	// online, so that it picks up rotated signing secrets
+	QRMaterialRefresh = time.Hour
+	// QROfflineSpecTTL is how long a POS device may verify codes with an offline spec
+	QROfflineSpecTTL = time.Hour
+	// QRAlgorithm names the signature scheme, for clients to check what they implement
+	QRAlgorithm = "HMAC-SHA256"
+)
+
+// QRCodePayload represents the data encoded in a wallet QR code
+type QRCodePayload struct {
+	WalletID   uuid.UUID `json:"w"`
+	FestivalID uuid.UUID `json:"f"`
+	Generation int       `json:"g"`
+	Timestamp  int64     `json:"t"` // Start of the rotation step, in Unix seconds
+	Signature  string    `json:"s"`
+}
+
+// QRMaterial lets the wallet app sign rotating QR codes without a connection. The seed
+// is specific to the wallet and its generation.
+type QRMaterial struct {
+	WalletID   uuid.UUID `json:"walletId"`
+	FestivalID uuid.UUID `json:"festivalId"`
+	Generation int       `json:"generation"`
+	Seed       string    `json:"seed"` // Base64 HMAC key the codes are signed with
+	Algorithm  string    `json:"algorithm"`
+	Period     int       `json:"period"`     // Rotation period in seconds
+	ServerTime time.Time `json:"serverTime"` // For the app to correct the clock of the phone
+	RefreshAt  time.Time `json:"refreshAt"`
+}
+
+// QROfflineKey is a festival key POS devices derive wallet seeds from. Keys of replaced
+// signing secrets are listed until the end of their overlap window.
+type QROfflineKey struct {
+	Fingerprint string     `json:"fingerprint"`
+	Key         string     `json:"key"` // Base64
+	Current     bool       `json:"current"`
+	AcceptUntil *time.Time `json:"acceptUntil,omitempty"`
+}
+
+// QRRevocation lists a wallet whose codes POS devices must refuse below a generation,
+// or altogether when the wallet is blocked
+type QRRevocation struct {
+	WalletID      uuid.UUID    `json:"walletId"`
+	MinGeneration int          `json:"minGeneration"`
+	Blocked       bool         `json:"blocked,omitempty"`      // Frozen or closed wallet
+	Reason        FreezeReason `json:"freezeReason,omitempty"` // Of a frozen wallet, shown to the staff
+}
+
+// QROfflineSpec is what a POS device needs to verify wallet QR codes while offline.
+// A code is accepted when:
+//   - its festival is the festival of the device
+//   - its step, t divided by the period, is within SkewSteps of the current step
+//   - its signature matches, for one of the keys, HMAC(seed, "<w>:<f>:<g>:<t>") where
+//     seed is HMAC(key, "<w>:<g>")
+//   - its wallet is not blocked and g is not below the minimum generation listed
+//
+// Wristbands whose UID is listed in RevokedWristbands, replaced at the help desk, are
+// refused too. Devices refresh the spec whenever they are online and stop accepting
+// codes offline once ValidUntil has passed.
+type QROfflineSpec struct {
+	FestivalID        uuid.UUID      `json:"festivalId"`
+	Algorithm         string         `json:"algorithm"`
+	Period            int            `json:"period"`
+	SkewSteps         int            `json:"skewSteps"`
+	Keys              []QROfflineKey `json:"keys"`
+	Revocations       []QRRevocation `json:"revocations"`
+	RevokedWristbands []string       `json:"revokedWristbands"`
+	IssuedAt          time.Time      `json:"issuedAt"`
+	ValidUntil        time.Time      `json:"validUntil"`
+}
+
+// GenerateQRPayload generates the signed QR code payload of the current step for a
+// wallet, for apps that do not sign codes themselves
+func (s *Service) GenerateQRPayload(ctx context.Context, walletID uuid.UUID) (string, error) {
+	wallet, err := s.repo.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return "", err
+	}
+	if wallet == nil {
+		return "", errors.ErrNotFound
+	}
+
+	payload := QRCodePayload{
+		WalletID:   wallet.ID,
+		FestivalID: wallet.FestivalID,
+		Generation: wallet.QRGeneration,
+		Timestamp:  s.qrStep(time.Now()) * s.qrPeriodSeconds(),
+	}
+	payload.Signature = s.signPayload(payload)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal QR payload: %w", err)
+	}
+
+	// Base64 encode for QR code
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// QRCodeExpiresAt returns when a code generated now stops being accepted
+func (s *Service) QRCodeExpiresAt(now time.Time) time.Time {
+	return time.Unix((s.qrStep(now)+QRSkewSteps+1)*s.qrPeriodSeconds(), 0)
+}
+
+// ValidateQRPayload validates a QR code payload. Codes outside the steps accepted, of
+// a previous generation or of a wallet that is not active are refused.
+func (s *Service) ValidateQRPayload(ctx context.Context, encoded string) (*QRCodePayload, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QR code format")
+	}
+
+	var payload QRCodePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid QR code data")
+	}
+
+	step := payload.Timestamp / s.qrPeriodSeconds()
+	current := s.qrStep(time.Now())
+	if step < current-QRSkewSteps {
+		return nil, ErrQRCodeExpired
+	}
+	if step > current+QRSkewSteps {
+		return nil, ErrQRCodeNotYetValid
+	}
+
+	// Verify signature, also with the previous secret during a rotation
+	signed := payload
+	signed.Signature = ""
+	valid := s.secrets.Verify(payload.Signature, func(secret []byte) string {
+		return signQR(qrWalletSeed(qrFestivalKey(secret, payload.FestivalID), payload.WalletID, payload.Generation), signed)
+	})
+	if !valid {
+		return nil, ErrQRCodeSignature
+	}
+
+	wallet, err := s.repo.GetWalletByID(ctx, payload.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil || wallet.FestivalID != payload.FestivalID {
+		return nil, errors.ErrNotFound
+	}
+	if wallet.Status != WalletStatusActive {
+		return nil, ErrQRWalletNotActive
+	}
+	if payload.Generation != wallet.QRGeneration {
+		return nil, ErrQRCodeRevoked
+	}
+
+	return &payload, nil
+}
+
+// GetQRMaterial returns the material the wallet app signs rotating QR codes with
+func (s *Service) GetQRMaterial(ctx context.Context, walletID uuid.UUID) (*QRMaterial, error) {
+	wallet, err := s.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status != WalletStatusActive {
+		return nil, ErrQRWalletNotActive
+	}
+	return s.qrMaterial(wallet), nil
+}
+
+// RotateQRMaterial revokes the QR material of a wallet and every code signed with it,
+// e.g. when the phone showing them was lost, and returns the new material
+func (s *Service) RotateQRMaterial(ctx context.Context, walletID uuid.UUID) (*QRMaterial, error) {
+	wallet, err := s.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status != WalletStatusActive {
+		return nil, ErrQRWalletNotActive
+	}
+
+	wallet.QRGeneration++
+	wallet.UpdatedAt = time.Now()
+	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
+		return nil, fmt.Errorf("failed to rotate QR material: %w", err)
+	}
+
+	return s.qrMaterial(wallet), nil
+}
+
+// GetQROfflineSpec returns the keys and revocations POS devices of a festival verify
+// wallet QR codes and wristbands with while offline
+func (s *Service) GetQROfflineSpec(ctx context.Context, festivalID uuid.UUID) (*QROfflineSpec, error) {
+	wallets, err := s.repo.GetQRRevocations(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	wristbands, err := s.repo.GetRevokedWristbands(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	acceptUntil := make(map[string]*time.Time)
+	for _, key := range s.secrets.Keys() {
+		acceptUntil[key.Fingerprint] = key.AcceptUntil
+	}
+
+	now := time.Now()
+	spec := &QROfflineSpec{
+		FestivalID:        festivalID,
+		Algorithm:         QRAlgorithm,
+		Period:            int(s.qrPeriodSeconds()),
+		SkewSteps:         QRSkewSteps,
+		Revocations:       make([]QRRevocation, len(wallets)),
+		RevokedWristbands: wristbands,
+		IssuedAt:          now,
+		ValidUntil:        now.Add(QROfflineSpecTTL),
+	}
+	for i, secret := range s.secrets.Accepted() {
+		fingerprint := security.Fingerprint(secret)
+		spec.Keys = append(spec.Keys, QROfflineKey{
+			Fingerprint: fingerprint,
+			Key:         base64.StdEncoding.EncodeToString(qrFestivalKey(secret, festivalID)),
+			Current:     i == 0,
+			AcceptUntil: acceptUntil[fingerprint],
+		})
+	}
+	for i, w := range wallets {
+		spec.Revocations[i] = QRRevocation{
+			WalletID:      w.ID,
+			MinGeneration: w.QRGeneration,
+			Blocked:       w.Status != WalletStatusActive,
+			Reason:        w.FreezeReason,
+		}
+	}
+
+	return spec, nil
+}
+
+func (s *Service) qrMaterial(wallet *Wallet) *QRMaterial {
+	now := time.Now()
+	seed := qrWalletSeed(qrFestivalKey(s.secrets.Current(), wallet.FestivalID), wallet.ID, wallet.QRGeneration)
+	return &QRMaterial{
+		WalletID:   wallet.ID,
+		FestivalID: wallet.FestivalID,
+		Generation: wallet.QRGeneration,
+		Seed:       base64.StdEncoding.EncodeToString(seed),
+		Algorithm:  QRAlgorithm,
+		Period:     int(s.qrPeriodSeconds()),
+		ServerTime: now,
+		RefreshAt:  now.Add(QRMaterialRefresh),
+	}
+}
+
+func (s *Service) qrPeriodSeconds() int64 {
+	return int64(s.qrPeriod / time.Second)
+}
+
+func (s *Service) qrStep(at time.Time) int64 {
+	return at.Unix() / s.qrPeriodSeconds()
+}
+
+func (s *Service) signPayload(payload QRCodePayload) string {
+	seed := qrWalletSeed(qrFestivalKey(s.secrets.Current(), payload.FestivalID), payload.WalletID, payload.Generation)
+	return signQR(seed, payload)
+}
+
+// qrFestivalKey derives the key of a festival, so that the keys handed to POS devices
+// cannot sign codes for other festivals
+func qrFestivalKey(secret []byte, festivalID uuid.UUID) []byte {
+	return hmacSHA256(secret, "wallet-qr:"+festivalID.String())
+}
+
+func qrWalletSeed(festivalKey []byte, walletID uuid.UUID, generation int) []byte {
+	return hmacSHA256(festivalKey, fmt.Sprintf("%s:%d", walletID, generation))
+}
+
+func signQR(seed []byte, payload QRCodePayload) string {
+	data := fmt.Sprintf("%s:%s:%d:%d", payload.WalletID, payload.FestivalID, payload.Generation, payload.Timestamp)
+	return base64.StdEncoding.EncodeToString(hmacSHA256(seed, data))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -2,10 +2,14 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
@@ -13,14 +17,25 @@ import (
 // Default query timeout for wallet operations
 const defaultQueryTimeout = 10 * time.Second
 
+// walletHistoryScope matches the transactions of a wallet and of the wallets merged
+// into it, leaving out the MERGE transactions that emptied the merged wallets
+const walletHistoryScope = "(wallet_id = ? OR (wallet_id IN (SELECT id FROM wallets WHERE merged_into_id = ?) AND type <> 'MERGE'))"
+
 type Repository interface {
 	// Wallet operations
 	CreateWallet(ctx context.Context, wallet *Wallet) error
+	CreateWalletWithTransaction(ctx context.Context, wallet *Wallet, tx *Transaction) error
+	GetWalletByClaimCode(ctx context.Context, claimCodeHash string) (*Wallet, error)
+	ClaimWallet(ctx context.Context, walletID, userID uuid.UUID, claimedAt time.Time) error
 	GetWalletByID(ctx context.Context, id uuid.UUID) (*Wallet, error)
 	GetWalletByUserAndFestival(ctx context.Context, userID, festivalID uuid.UUID) (*Wallet, error)
 	GetWalletsByUser(ctx context.Context, userID uuid.UUID) ([]Wallet, error)
 	GetWalletsByFestival(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Wallet, int64, error)
 	UpdateWallet(ctx context.Context, wallet *Wallet) error
+	GetQRRevocations(ctx context.Context, festivalID uuid.UUID) ([]Wallet, error)
+	ThawDueWallets(ctx context.Context, now time.Time, scope clock.Scope) ([]Wallet, error)
+	GetWalletHolder(ctx context.Context, walletID uuid.UUID) (*WalletHolder, error)
+	SetActivityPush(ctx context.Context, wallet *Wallet) error
 
 	// Transaction operations
 	CreateTransaction(ctx context.Context, tx *Transaction) error
@@ -35,11 +50,36 @@ type Repository interface {
 	ProcessPayment(ctx context.Context, walletID uuid.UUID, amount int64, txData *Transaction) error
 	ProcessPaymentWithRetry(ctx context.Context, walletID uuid.UUID, amount int64, txData *Transaction, maxRetries int) error
 	TopUpAtomic(ctx context.Context, walletID uuid.UUID, amount int64, txData *Transaction) error
+	AdjustAtomic(ctx context.Context, walletID uuid.UUID, txData *Transaction) error
 	RefundAtomic(ctx context.Context, walletID uuid.UUID, amount int64, refundTx *Transaction, originalTxID uuid.UUID) error
+	ReplacePaymentAtomic(ctx context.Context, walletID uuid.UUID, refundTx, purchaseTx *Transaction, amount int64, originalTxID uuid.UUID) error
+	MergeWalletsAtomic(ctx context.Context, merge *WalletMerge, confirmedBy *uuid.UUID) error
+	ReplaceWristbandAtomic(ctx context.Context, replacement *WristbandReplacement, oldUID string, claimCodeHash *string) (*Wallet, error)
+
+	// Merge operations
+	CreateMerge(ctx context.Context, merge *WalletMerge) error
+	GetMergeByID(ctx context.Context, id uuid.UUID) (*WalletMerge, error)
+	GetOpenMergeBySource(ctx context.Context, sourceWalletID uuid.UUID) (*WalletMerge, error)
+	GetMergesByUser(ctx context.Context, userID uuid.UUID) ([]WalletMerge, error)
+	CancelMerge(ctx context.Context, merge *WalletMerge) error
+
+	// Wristband replacement operations
+	GetWristbandReplacements(ctx context.Context, walletID uuid.UUID) ([]WristbandReplacement, error)
+	GetRevokedWristbands(ctx context.Context, festivalID uuid.UUID) ([]string, error)
 
 	// Aggregation operations
 	GetWalletStats(ctx context.Context, festivalID uuid.UUID) (*WalletStats, error)
 	GetTransactionSummary(ctx context.Context, walletID uuid.UUID, start, end time.Time) (*TransactionSummary, error)
+
+	// Name lookups of the activity history
+	GetStandNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error)
+	GetProductNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error)
+
+	// Activity digest operations
+	ListDigestFestivals(ctx context.Context, scope clock.Scope) ([]DigestFestival, error)
+	ListDueDigestWallets(ctx context.Context, festivalID uuid.UUID, periodEnd time.Time) ([]DigestWallet, error)
+	MarkDigestSent(ctx context.Context, walletID uuid.UUID, periodEnd time.Time) (bool, error)
+	GetDigestSpending(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]StandSpending, error)
 }
 
 // WalletStats contains aggregated wallet statistics
@@ -80,6 +120,74 @@ func (r *repository) CreateWallet(ctx context.Context, wallet *Wallet) error {
 	return r.db.WithContext(ctx).Create(wallet).Error
 }
 
+// CreateWalletWithTransaction creates a wallet funded by its first transaction,
+// so that cash taken at the entrance is never recorded without its wallet
+func (r *repository) CreateWalletWithTransaction(ctx context.Context, wallet *Wallet, tx *Transaction) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		tx.BalanceBefore = 0
+		tx.BalanceAfter = tx.Amount
+		wallet.Balance = tx.Amount
+
+		if err := dbTx.Create(wallet).Error; err != nil {
+			return fmt.Errorf("failed to create wallet: %w", err)
+		}
+		if err := dbTx.Create(tx).Error; err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetWalletByClaimCode(ctx context.Context, claimCodeHash string) (*Wallet, error) {
+	var wallet Wallet
+	err := r.db.WithContext(ctx).Where("claim_code_hash = ?", claimCodeHash).First(&wallet).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return &wallet, nil
+}
+
+// ClaimWallet attaches an anonymous wallet and its wristbands to a user. It fails
+// with ErrWalletAlreadyClaimed if the wallet got a user in the meantime.
+func (r *repository) ClaimWallet(ctx context.Context, walletID, userID uuid.UUID, claimedAt time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		result := dbTx.Model(&Wallet{}).
+			Where("id = ? AND user_id IS NULL", walletID).
+			Updates(map[string]interface{}{
+				"user_id":    userID,
+				"claimed_at": claimedAt,
+				"updated_at": claimedAt,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim wallet: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWalletAlreadyClaimed
+		}
+
+		for _, table := range []string{"nfc_tags", "nfc_bracelets"} {
+			if err := dbTx.Table(table).
+				Where("wallet_id = ?", walletID).
+				Updates(map[string]interface{}{
+					"user_id":    userID,
+					"updated_at": claimedAt,
+				}).Error; err != nil {
+				return fmt.Errorf("failed to update %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
 func (r *repository) GetWalletByID(ctx context.Context, id uuid.UUID) (*Wallet, error) {
 	var wallet Wallet
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(&wallet).Error
@@ -147,14 +255,14 @@ func (r *repository) GetTransactionsByWallet(ctx context.Context, walletID uuid.
 
 	// Use optimized counting with index hint
 	// The idx_transactions_wallet_created index will be used
-	countQuery := r.db.WithContext(ctx).Model(&Transaction{}).Where("wallet_id = ?", walletID)
+	countQuery := r.db.WithContext(ctx).Model(&Transaction{}).Where(walletHistoryScope, walletID, walletID)
 	if err := countQuery.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count transactions: %w", err)
 	}
 
 	// Fetch data using the composite index (wallet_id, created_at DESC)
 	if err := r.db.WithContext(ctx).
-		Where("wallet_id = ?", walletID).
+		Where(walletHistoryScope, walletID, walletID).
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
@@ -175,7 +283,8 @@ func (r *repository) GetTransactionsByWalletWithDateRange(ctx context.Context, w
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&Transaction{}).
-		Where("wallet_id = ? AND created_at >= ? AND created_at < ?", walletID, start, end)
+		Where(walletHistoryScope, walletID, walletID).
+		Where("created_at >= ? AND created_at < ?", start, end)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count transactions: %w", err)
@@ -249,6 +358,223 @@ func (r *repository) GetWalletsByFestival(ctx context.Context, festivalID uuid.U
 	return wallets, total, nil
 }
 
+// GetStandNames returns the names of stands by ID
+func (r *repository) GetStandNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	return r.getNames(ctx, "stands", ids)
+}
+
+// GetProductNames returns the names of products by ID
+func (r *repository) GetProductNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	return r.getNames(ctx, "products", ids)
+}
+
+func (r *repository) getNames(ctx context.Context, table string, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var rows []struct {
+		ID   uuid.UUID
+		Name string
+	}
+	if err := r.db.WithContext(ctx).Table(table).Select("id, name").Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get %s names: %w", table, err)
+	}
+
+	names := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, nil
+}
+
+// GetQRRevocations returns the wallets of a festival whose QR material was rotated or
+// which are not active, with their ID, status and QR generation only
+// Uses the idx_wallets_qr_revocations partial index
+func (r *repository) GetQRRevocations(ctx context.Context, festivalID uuid.UUID) ([]Wallet, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var wallets []Wallet
+	err := r.db.WithContext(ctx).
+		Select("id, status, qr_generation, freeze_reason").
+		Where("festival_id = ? AND (qr_generation > 0 OR status <> ?)", festivalID, WalletStatusActive).
+		Find(&wallets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get QR revocations: %w", err)
+	}
+	return wallets, nil
+}
+
+// ThawDueWallets unfreezes the frozen wallets whose automatic unfreeze is due and
+// returns them with the reason they were frozen for, among the festivals of the scope.
+// Uses the idx_wallets_frozen_until partial index.
+func (r *repository) ThawDueWallets(ctx context.Context, now time.Time, scope clock.Scope) ([]Wallet, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{WalletStatusActive, now, WalletStatusFrozen, now}
+	var filter string
+	if scope.FestivalID != nil {
+		filter += " AND festival_id = ?"
+		args = append(args, *scope.FestivalID)
+	}
+	if len(scope.Exclude) > 0 {
+		filter += " AND festival_id NOT IN ?"
+		args = append(args, scope.Exclude)
+	}
+
+	var wallets []Wallet
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE wallets w
+		SET status = ?, updated_at = ?,
+			freeze_reason = NULL, freeze_note = NULL, frozen_at = NULL, frozen_by = NULL, frozen_until = NULL
+		FROM (
+			SELECT id, freeze_reason FROM wallets
+			WHERE status = ? AND frozen_until IS NOT NULL AND frozen_until <= ?`+filter+`
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE w.id = due.id
+		RETURNING w.id, w.user_id, w.festival_id, w.status, w.updated_at, due.freeze_reason`,
+		args...,
+	).Scan(&wallets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to thaw wallets: %w", err)
+	}
+	return wallets, nil
+}
+
+// GetWalletHolder returns the contact of the user a wallet belongs to, nil for an
+// anonymous wallet
+func (r *repository) GetWalletHolder(ctx context.Context, walletID uuid.UUID) (*WalletHolder, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var holders []WalletHolder
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT u.id AS user_id, u.email, COALESCE(p.preferred_language, '') AS locale,
+			w.festival_id, f.name AS festival_name, f.currency_name, w.activity_push
+		FROM wallets w
+		INNER JOIN users u ON u.id = w.user_id
+		INNER JOIN festivals f ON f.id = w.festival_id
+		LEFT JOIN user_notification_preferences p ON p.user_id = w.user_id
+		WHERE w.id = ?`,
+		walletID,
+	).Scan(&holders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet holder: %w", err)
+	}
+	if len(holders) == 0 {
+		return nil, nil
+	}
+	return &holders[0], nil
+}
+
+// SetActivityPush saves how the holder of a wallet is told about its activity, and since
+// when its digests summarize it
+func (r *repository) SetActivityPush(ctx context.Context, wallet *Wallet) error {
+	err := r.db.WithContext(ctx).Model(&Wallet{}).Where("id = ?", wallet.ID).
+		Updates(map[string]interface{}{
+			"activity_push":           wallet.ActivityPush,
+			"last_activity_digest_at": wallet.LastDigestAt,
+			"updated_at":              wallet.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to set activity push mode: %w", err)
+	}
+	return nil
+}
+
+// ListDigestFestivals returns the active festivals of the scope, whose wallets get
+// activity digests
+func (r *repository) ListDigestFestivals(ctx context.Context, scope clock.Scope) ([]DigestFestival, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Table("festivals").
+		Select("id, name, timezone, currency_name").
+		Where("status = ?", "ACTIVE")
+	if scope.FestivalID != nil {
+		query = query.Where("id = ?", *scope.FestivalID)
+	}
+	if len(scope.Exclude) > 0 {
+		query = query.Where("id NOT IN ?", scope.Exclude)
+	}
+
+	var festivals []DigestFestival
+	if err := query.Scan(&festivals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list digest festivals: %w", err)
+	}
+	return festivals, nil
+}
+
+// ListDueDigestWallets returns the claimed wallets of a festival in digest mode, that
+// have not had the digest of the period ending at periodEnd, with the push preferences
+// of their holder. Holders who turned push notifications off are left out. Uses the
+// idx_wallets_activity_digest partial index.
+func (r *repository) ListDueDigestWallets(ctx context.Context, festivalID uuid.UUID, periodEnd time.Time) ([]DigestWallet, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var wallets []DigestWallet
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT w.id AS wallet_id, w.user_id, w.balance, w.last_activity_digest_at AS last_digest_at,
+			COALESCE(p.preferred_language, '') AS locale,
+			COALESCE(p.quiet_hours_enabled, FALSE) AS quiet_hours_enabled,
+			COALESCE(p.quiet_hours_start, '') AS quiet_hours_start,
+			COALESCE(p.quiet_hours_end, '') AS quiet_hours_end
+		FROM wallets w
+		LEFT JOIN user_notification_preferences p ON p.user_id = w.user_id
+		WHERE w.festival_id = ? AND w.activity_push = ? AND w.user_id IS NOT NULL
+			AND w.merged_into_id IS NULL AND w.status <> ? AND w.created_at < ?
+			AND (w.last_activity_digest_at IS NULL OR w.last_activity_digest_at < ?)
+			AND COALESCE(p.global_push_enabled, TRUE)`,
+		festivalID, ActivityPushDigest, WalletStatusClosed, periodEnd, periodEnd,
+	).Scan(&wallets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due digest wallets: %w", err)
+	}
+	return wallets, nil
+}
+
+// MarkDigestSent records that the digest of the period ending at periodEnd was sent for
+// a wallet, returning false when it already was, e.g. by a concurrent run
+func (r *repository) MarkDigestSent(ctx context.Context, walletID uuid.UUID, periodEnd time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&Wallet{}).
+		Where("id = ? AND (last_activity_digest_at IS NULL OR last_activity_digest_at < ?)", walletID, periodEnd).
+		UpdateColumn("last_activity_digest_at", periodEnd)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark digest sent: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetDigestSpending returns what a wallet spent per stand between from and to,
+// purchases net of their refunds, the most spent first. The wallets merged into it
+// are included.
+func (r *repository) GetDigestSpending(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]StandSpending, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var spending []StandSpending
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT t.stand_id, COALESCE(s.name, '') AS stand_name,
+			-SUM(t.amount) AS spent,
+			COUNT(*) FILTER (WHERE t.type = ?) AS purchases
+		FROM transactions t
+		LEFT JOIN stands s ON s.id = t.stand_id
+		WHERE (t.wallet_id = ? OR t.wallet_id IN (SELECT id FROM wallets WHERE merged_into_id = ?))
+			AND t.type IN ? AND t.status = ? AND t.created_at >= ? AND t.created_at < ?
+		GROUP BY t.stand_id, s.name
+		ORDER BY spent DESC, stand_name`,
+		TransactionTypePurchase, walletID, walletID,
+		[]TransactionType{TransactionTypePurchase, TransactionTypeRefund}, TransactionStatusCompleted, from, to,
+	).Scan(&spending).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest spending: %w", err)
+	}
+	return spending, nil
+}
+
 // GetWalletStats returns aggregated wallet statistics for a festival
 // Optimized aggregation query using the idx_wallets_festival_status index
 func (r *repository) GetWalletStats(ctx context.Context, festivalID uuid.UUID) (*WalletStats, error) {
@@ -317,16 +643,16 @@ func (r *repository) ProcessPayment(ctx context.Context, walletID uuid.UUID, amo
 			return fmt.Errorf("wallet not found")
 		}
 
+		// Check wallet status
+		if err := checkUsable(&wallet); err != nil {
+			return err
+		}
+
 		// Check sufficient balance
 		if wallet.Balance < amount {
 			return fmt.Errorf("insufficient balance")
 		}
 
-		// Check wallet status
-		if wallet.Status != WalletStatusActive {
-			return fmt.Errorf("wallet is not active")
-		}
-
 		// Update balance using optimistic update
 		newBalance := wallet.Balance - amount
 		result := dbTx.Model(&Wallet{}).
@@ -374,8 +700,8 @@ func (r *repository) TopUpAtomic(ctx context.Context, walletID uuid.UUID, amount
 		}
 
 		// Check wallet status
-		if wallet.Status != WalletStatusActive {
-			return fmt.Errorf("wallet is not active")
+		if err := checkUsable(&wallet); err != nil {
+			return err
 		}
 
 		// Calculate new balance
@@ -410,6 +736,62 @@ func (r *repository) TopUpAtomic(ctx context.Context, walletID uuid.UUID, amount
 	})
 }
 
+// AdjustAtomic atomically credits or debits a wallet by the amount of the transaction.
+// A debit never takes the balance below zero, and a transaction already recorded is
+// not applied again.
+func (r *repository) AdjustAtomic(ctx context.Context, walletID uuid.UUID, txData *Transaction) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		var wallet Wallet
+		if err := dbTx.Raw("SELECT * FROM wallets WHERE id = ? FOR UPDATE", walletID).
+			Scan(&wallet).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+		if wallet.ID == uuid.Nil {
+			return fmt.Errorf("wallet not found")
+		}
+
+		// Retried adjustments reuse their transaction ID
+		var applied int64
+		if err := dbTx.Model(&Transaction{}).Where("id = ?", txData.ID).Count(&applied).Error; err != nil {
+			return fmt.Errorf("failed to check adjustment: %w", err)
+		}
+		if applied > 0 {
+			return ErrAdjustmentApplied
+		}
+
+		if wallet.Status != WalletStatusActive {
+			return ErrAdjustmentNotActive
+		}
+		newBalance := wallet.Balance + txData.Amount
+		if newBalance < 0 {
+			return ErrAdjustmentInsufficient
+		}
+
+		result := dbTx.Model(&Wallet{}).
+			Where("id = ? AND balance = ?", walletID, wallet.Balance).
+			Updates(map[string]interface{}{
+				"balance":    newBalance,
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update balance: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("concurrent modification detected, please retry")
+		}
+
+		txData.BalanceBefore = wallet.Balance
+		txData.BalanceAfter = newBalance
+		if err := dbTx.Create(txData).Error; err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		return nil
+	})
+}
+
 // RefundAtomic atomically processes a refund
 func (r *repository) RefundAtomic(ctx context.Context, walletID uuid.UUID, amount int64, refundTx *Transaction, originalTxID uuid.UUID) error {
 	ctx, cancel := r.withTimeout(ctx)
@@ -467,6 +849,318 @@ func (r *repository) RefundAtomic(ctx context.Context, walletID uuid.UUID, amoun
 	})
 }
 
+// ReplacePaymentAtomic atomically refunds a purchase and charges its replacement,
+// so the wallet never holds the refunded amount without the new charge
+func (r *repository) ReplacePaymentAtomic(ctx context.Context, walletID uuid.UUID, refundTx, purchaseTx *Transaction, amount int64, originalTxID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		// Lock the wallet row for update
+		var wallet Wallet
+		if err := dbTx.Raw("SELECT * FROM wallets WHERE id = ? FOR UPDATE", walletID).
+			Scan(&wallet).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+
+		// Check if wallet was found
+		if wallet.ID == uuid.Nil {
+			return fmt.Errorf("wallet not found")
+		}
+
+		// Check wallet status
+		if err := checkUsable(&wallet); err != nil {
+			return err
+		}
+
+		// Check sufficient balance once the original purchase is refunded
+		refundedBalance := wallet.Balance + refundTx.Amount
+		if refundedBalance < amount {
+			return fmt.Errorf("insufficient balance")
+		}
+		newBalance := refundedBalance - amount
+
+		// Set transaction balances
+		refundTx.BalanceBefore = wallet.Balance
+		refundTx.BalanceAfter = refundedBalance
+		purchaseTx.BalanceBefore = refundedBalance
+		purchaseTx.BalanceAfter = newBalance
+		purchaseTx.Amount = -amount // Negative for debit
+
+		// Update wallet balance
+		result := dbTx.Model(&Wallet{}).
+			Where("id = ? AND balance = ?", walletID, wallet.Balance).
+			Updates(map[string]interface{}{
+				"balance":    newBalance,
+				"updated_at": time.Now(),
+			})
+
+		if result.Error != nil {
+			return fmt.Errorf("failed to update balance: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("concurrent modification detected, please retry")
+		}
+
+		// Create refund and replacement transaction records
+		if err := dbTx.Create(refundTx).Error; err != nil {
+			return fmt.Errorf("failed to create refund transaction: %w", err)
+		}
+		if err := dbTx.Create(purchaseTx).Error; err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		// Mark original transaction as refunded
+		if err := dbTx.Model(&Transaction{}).
+			Where("id = ?", originalTxID).
+			Update("status", TransactionStatusRefunded).Error; err != nil {
+			return fmt.Errorf("failed to update original transaction: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// MergeWalletsAtomic atomically moves the balance and wristbands of the merge's
+// source wallet to its target wallet and closes the source wallet. Both wallets
+// are locked in id order so that concurrent merges cannot deadlock. Confirming a
+// merge that already completed leaves it unchanged, so retries are safe.
+func (r *repository) MergeWalletsAtomic(ctx context.Context, merge *WalletMerge, confirmedBy *uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		// Lock the merge row so that concurrent confirmations run one at a time
+		var locked WalletMerge
+		if err := dbTx.Raw("SELECT * FROM wallet_merges WHERE id = ? FOR UPDATE", merge.ID).
+			Scan(&locked).Error; err != nil {
+			return fmt.Errorf("failed to lock merge: %w", err)
+		}
+		if locked.ID == uuid.Nil {
+			return fmt.Errorf("merge not found")
+		}
+		if locked.Status == MergeStatusCompleted {
+			*merge = locked
+			return nil
+		}
+		if locked.Status != MergeStatusPending {
+			return ErrMergeNotPending
+		}
+
+		// Lock both wallets
+		var wallets []Wallet
+		if err := dbTx.Raw("SELECT * FROM wallets WHERE id IN (?, ?) ORDER BY id FOR UPDATE",
+			locked.SourceWalletID, locked.TargetWalletID).Scan(&wallets).Error; err != nil {
+			return fmt.Errorf("failed to lock wallets: %w", err)
+		}
+
+		var source, target *Wallet
+		for i := range wallets {
+			switch wallets[i].ID {
+			case locked.SourceWalletID:
+				source = &wallets[i]
+			case locked.TargetWalletID:
+				target = &wallets[i]
+			}
+		}
+		if source == nil || target == nil {
+			return fmt.Errorf("wallet not found")
+		}
+		if source.MergedIntoID != nil {
+			return ErrWalletAlreadyMerged
+		}
+		if source.Status != WalletStatusActive || target.Status != WalletStatusActive {
+			return ErrMergeWalletNotActive
+		}
+
+		now := time.Now()
+		amount := source.Balance
+		reference := "merge:" + locked.ID.String()
+
+		if err := dbTx.Model(&Transaction{}).
+			Where(walletHistoryScope, source.ID, source.ID).
+			Count(&locked.TransactionCount).Error; err != nil {
+			return fmt.Errorf("failed to count source transactions: %w", err)
+		}
+
+		// Record the balance leaving the source wallet and entering the target wallet
+		if amount != 0 {
+			transfers := []Transaction{
+				{
+					ID:            uuid.New(),
+					WalletID:      source.ID,
+					Type:          TransactionTypeMerge,
+					Amount:        -amount,
+					BalanceBefore: source.Balance,
+					BalanceAfter:  0,
+					Reference:     reference,
+					StaffID:       confirmedBy,
+					Metadata:      TransactionMeta{Description: "Merged into wallet " + target.ID.String()},
+					Status:        TransactionStatusCompleted,
+					CreatedAt:     now,
+				},
+				{
+					ID:            uuid.New(),
+					WalletID:      target.ID,
+					Type:          TransactionTypeMerge,
+					Amount:        amount,
+					BalanceBefore: target.Balance,
+					BalanceAfter:  target.Balance + amount,
+					Reference:     reference,
+					StaffID:       confirmedBy,
+					Metadata:      TransactionMeta{Description: "Merged from wallet " + source.ID.String()},
+					Status:        TransactionStatusCompleted,
+					CreatedAt:     now,
+				},
+			}
+			if err := dbTx.Create(&transfers).Error; err != nil {
+				return fmt.Errorf("failed to create merge transactions: %w", err)
+			}
+		}
+
+		if err := dbTx.Model(&Wallet{}).
+			Where("id = ?", target.ID).
+			Updates(map[string]interface{}{
+				"balance":    target.Balance + amount,
+				"updated_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update target wallet: %w", err)
+		}
+
+		if err := dbTx.Model(&Wallet{}).
+			Where("id = ?", source.ID).
+			Updates(map[string]interface{}{
+				"balance":        0,
+				"status":         WalletStatusClosed,
+				"merged_into_id": target.ID,
+				"updated_at":     now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to close source wallet: %w", err)
+		}
+
+		// Wallets previously merged into the source now belong to the target history
+		if err := dbTx.Model(&Wallet{}).
+			Where("merged_into_id = ?", source.ID).
+			Updates(map[string]interface{}{
+				"merged_into_id": target.ID,
+				"updated_at":     now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to repoint merged wallets: %w", err)
+		}
+
+		// Keep the wristbands of the source wallet working on the target wallet
+		var relinked int64
+		for _, table := range []string{"nfc_tags", "nfc_bracelets"} {
+			result := dbTx.Table(table).
+				Where("wallet_id = ?", source.ID).
+				Updates(map[string]interface{}{
+					"wallet_id":  target.ID,
+					"user_id":    target.UserID,
+					"updated_at": now,
+				})
+			if result.Error != nil {
+				return fmt.Errorf("failed to relink %s: %w", table, result.Error)
+			}
+			relinked += result.RowsAffected
+		}
+
+		locked.Status = MergeStatusCompleted
+		locked.Amount = amount
+		locked.TargetBalanceBefore = target.Balance
+		locked.TargetBalanceAfter = target.Balance + amount
+		locked.TagsRelinked = relinked
+		locked.ConfirmedBy = confirmedBy
+		locked.ConfirmedAt = &now
+		locked.UpdatedAt = now
+
+		if err := dbTx.Save(&locked).Error; err != nil {
+			return fmt.Errorf("failed to complete merge: %w", err)
+		}
+
+		*merge = locked
+		return nil
+	})
+}
+
+func (r *repository) CreateMerge(ctx context.Context, merge *WalletMerge) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Create(merge).Error; err != nil {
+		// The source wallet already has a pending or completed merge
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrMergeAlreadyPending
+		}
+		return fmt.Errorf("failed to create merge: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetMergeByID(ctx context.Context, id uuid.UUID) (*WalletMerge, error) {
+	var merge WalletMerge
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&merge).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get merge: %w", err)
+	}
+	return &merge, nil
+}
+
+// GetOpenMergeBySource returns the pending or completed merge of a source wallet
+func (r *repository) GetOpenMergeBySource(ctx context.Context, sourceWalletID uuid.UUID) (*WalletMerge, error) {
+	var merge WalletMerge
+	err := r.db.WithContext(ctx).
+		Where("source_wallet_id = ? AND status IN ?", sourceWalletID, []MergeStatus{MergeStatusPending, MergeStatusCompleted}).
+		First(&merge).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get merge: %w", err)
+	}
+	return &merge, nil
+}
+
+// GetMergesByUser returns the merges into or out of the wallets of a user
+func (r *repository) GetMergesByUser(ctx context.Context, userID uuid.UUID) ([]WalletMerge, error) {
+	var merges []WalletMerge
+	err := r.db.WithContext(ctx).
+		Where("target_user_id = ? OR source_user_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&merges).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merges: %w", err)
+	}
+	return merges, nil
+}
+
+// CancelMerge cancels a merge if it is still pending
+func (r *repository) CancelMerge(ctx context.Context, merge *WalletMerge) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&WalletMerge{}).
+		Where("id = ? AND status = ?", merge.ID, MergeStatusPending).
+		Updates(map[string]interface{}{
+			"status":       MergeStatusCancelled,
+			"cancelled_by": merge.CancelledBy,
+			"cancelled_at": merge.CancelledAt,
+			"updated_at":   merge.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to cancel merge: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrMergeNotPending
+	}
+	merge.Status = MergeStatusCancelled
+	return nil
+}
+
 // ProcessPaymentWithRetry processes a payment with automatic retry on deadlock
 func (r *repository) ProcessPaymentWithRetry(ctx context.Context, walletID uuid.UUID, amount int64, txData *Transaction, maxRetries int) error {
 	var lastErr error
@@ -499,6 +1193,192 @@ func (r *repository) ProcessPaymentWithRetry(ctx context.Context, walletID uuid.
 	return fmt.Errorf("payment processing failed after %d retries: %w", maxRetries, lastErr)
 }
 
+// ReplaceWristbandAtomic revokes the wristbands of a wallet, every one or only oldUID,
+// and links the new wristband of the replacement to it. The QR generation of the wallet
+// is bumped, its claim code replaced when claimCodeHash is set, and a freeze for the
+// lost or stolen wristband lifted. The replacement is completed with what was done.
+func (r *repository) ReplaceWristbandAtomic(ctx context.Context, replacement *WristbandReplacement, oldUID string, claimCodeHash *string) (*Wallet, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var wallet Wallet
+	err := r.db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Raw("SELECT * FROM wallets WHERE id = ? FOR UPDATE", replacement.WalletID).
+			Scan(&wallet).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+		if wallet.ID == uuid.Nil {
+			return fmt.Errorf("wallet not found")
+		}
+		if wallet.Status == WalletStatusClosed {
+			return ErrReplaceClosedWallet
+		}
+
+		// The new wristband is either unknown yet or registered unassigned for the festival
+		var bands []struct {
+			FestivalID uuid.UUID
+			Status     string
+		}
+		if err := dbTx.Raw(`
+			SELECT festival_id, status FROM nfc_bracelets WHERE uid = ?
+			UNION ALL
+			SELECT festival_id, status FROM nfc_tags WHERE uid = ?`,
+			replacement.NewUID, replacement.NewUID,
+		).Scan(&bands).Error; err != nil {
+			return fmt.Errorf("failed to get new wristband: %w", err)
+		}
+		registered := false
+		for _, band := range bands {
+			if band.FestivalID != wallet.FestivalID {
+				return ErrWristbandOtherFestival
+			}
+			if band.Status != "UNASSIGNED" {
+				return ErrWristbandInUse
+			}
+			registered = true
+		}
+
+		now := time.Now()
+
+		// Revoke the old wristbands, including those already reported lost or blocked
+		revoked := []string{}
+		for _, revoke := range []struct{ table, status string }{
+			{"nfc_bracelets", "REPLACED"},
+			{"nfc_tags", "BLOCKED"},
+		} {
+			query := "UPDATE " + revoke.table + ` SET status = ?, blocked_at = ?, block_reason = ?, updated_at = ?
+				WHERE wallet_id = ? AND status IN ('ACTIVE', 'LOST', 'BLOCKED')`
+			args := []interface{}{revoke.status, now, "Replaced: " + string(replacement.Reason), now, wallet.ID}
+			if oldUID != "" {
+				query += " AND uid = ?"
+				args = append(args, oldUID)
+			}
+
+			var uids []string
+			if err := dbTx.Raw(query+" RETURNING uid", args...).Scan(&uids).Error; err != nil {
+				return fmt.Errorf("failed to revoke %s: %w", revoke.table, err)
+			}
+			revoked = append(revoked, uids...)
+		}
+		if oldUID != "" && len(revoked) == 0 {
+			return ErrWristbandNotLinked
+		}
+
+		// Link the new wristband, registering it when unknown
+		var linkErr error
+		if registered {
+			linkErr = dbTx.Exec(`
+				UPDATE nfc_bracelets
+				SET wallet_id = ?, user_id = ?, status = 'ACTIVE', activated_at = ?, activated_by = ?,
+					metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('replacementFor', ?::text),
+					updated_at = ?
+				WHERE uid = ?`,
+				wallet.ID, wallet.UserID, now, replacement.ReplacedBy, strings.Join(revoked, ","), now, replacement.NewUID,
+			).Error
+			if linkErr == nil {
+				linkErr = dbTx.Exec(`
+					UPDATE nfc_tags SET wallet_id = ?, user_id = ?, status = 'ACTIVE', activated_at = ?, updated_at = ?
+					WHERE uid = ?`,
+					wallet.ID, wallet.UserID, now, now, replacement.NewUID,
+				).Error
+			}
+		} else {
+			linkErr = dbTx.Exec(`
+				INSERT INTO nfc_bracelets (uid, wallet_id, user_id, festival_id, status, activated_at, activated_by, metadata, created_at, updated_at)
+				VALUES (?, ?, ?, ?, 'ACTIVE', ?, ?, jsonb_build_object('replacementFor', ?::text), ?, ?)`,
+				replacement.NewUID, wallet.ID, wallet.UserID, wallet.FestivalID, now, replacement.ReplacedBy,
+				strings.Join(revoked, ","), now, now,
+			).Error
+		}
+		if linkErr != nil {
+			return fmt.Errorf("failed to link new wristband: %w", linkErr)
+		}
+
+		updates := map[string]interface{}{
+			"qr_generation": wallet.QRGeneration + 1,
+			"updated_at":    now,
+		}
+		if claimCodeHash != nil {
+			updates["claim_code_hash"] = *claimCodeHash
+			wallet.ClaimCodeHash = claimCodeHash
+		}
+		unfreeze := wallet.Status == WalletStatusFrozen &&
+			(wallet.FreezeReason == FreezeReasonLostWristband || wallet.FreezeReason == FreezeReasonStolen)
+		if unfreeze {
+			thaw(&wallet, now)
+			updates["status"] = WalletStatusActive
+			updates["freeze_reason"] = nil
+			updates["freeze_note"] = nil
+			updates["frozen_at"] = nil
+			updates["frozen_by"] = nil
+			updates["frozen_until"] = nil
+		}
+		if err := dbTx.Model(&Wallet{}).Where("id = ?", wallet.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+		wallet.QRGeneration++
+		wallet.UpdatedAt = now
+
+		replacement.FestivalID = wallet.FestivalID
+		replacement.OldUIDs = revoked
+		replacement.QRGeneration = wallet.QRGeneration
+		replacement.ClaimCodeReissued = claimCodeHash != nil
+		replacement.Unfrozen = unfreeze
+		replacement.CreatedAt = now
+		if err := dbTx.Create(replacement).Error; err != nil {
+			return fmt.Errorf("failed to create wristband replacement: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// GetWristbandReplacements returns the wristband replacements of a wallet, latest first
+func (r *repository) GetWristbandReplacements(ctx context.Context, walletID uuid.UUID) ([]WristbandReplacement, error) {
+	var replacements []WristbandReplacement
+	err := r.db.WithContext(ctx).
+		Where("wallet_id = ?", walletID).
+		Order("created_at DESC").
+		Find(&replacements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wristband replacements: %w", err)
+	}
+	return replacements, nil
+}
+
+// GetRevokedWristbands returns the UIDs of the wristbands replaced in a festival
+func (r *repository) GetRevokedWristbands(ctx context.Context, festivalID uuid.UUID) ([]string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var uids []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT unnest(old_uids) AS uid FROM wristband_replacements
+		WHERE festival_id = ?
+		ORDER BY uid`,
+		festivalID,
+	).Scan(&uids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revoked wristbands: %w", err)
+	}
+	return uids, nil
+}
+
+// checkUsable returns why a wallet cannot be charged or credited, nil if it can
+func checkUsable(wallet *Wallet) error {
+	switch wallet.Status {
+	case WalletStatusActive:
+		return nil
+	case WalletStatusFrozen:
+		return ErrWalletFrozen
+	default:
+		return fmt.Errorf("wallet is not active")
+	}
+}
+
 // isRetryableError checks if the error is retryable (deadlock or serialization failure)
 func isRetryableError(errStr string) bool {
 	retryablePatterns := []string{
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -84,6 +85,11 @@ func (m *MockRepository) GetWalletsByFestival(ctx context.Context, festivalID uu
 	return args.Get(0).([]Wallet), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockRepository) GetQRRevocations(ctx context.Context, festivalID uuid.UUID) ([]Wallet, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]Wallet), args.Error(1)
+}
+
 func (m *MockRepository) CreateTransactionsBatch(ctx context.Context, txs []Transaction) error {
 	args := m.Called(ctx, txs)
 	return args.Error(0)
@@ -125,7 +131,143 @@ func (m *MockRepository) TopUpAtomic(ctx context.Context, walletID uuid.UUID, am
 	return args.Error(0)
 }
 
+func (m *MockRepository) AdjustAtomic(ctx context.Context, walletID uuid.UUID, txData *Transaction) error {
+	args := m.Called(ctx, walletID, txData)
+	return args.Error(0)
+}
+
 func (m *MockRepository) RefundAtomic(ctx context.Context, walletID uuid.UUID, amount int64, refundTx *Transaction, originalTxID uuid.UUID) error {
 	args := m.Called(ctx, walletID, amount, refundTx, originalTxID)
 	return args.Error(0)
 }
+
+func (m *MockRepository) ReplacePaymentAtomic(ctx context.Context, walletID uuid.UUID, refundTx, purchaseTx *Transaction, amount int64, originalTxID uuid.UUID) error {
+	args := m.Called(ctx, walletID, refundTx, purchaseTx, amount, originalTxID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MergeWalletsAtomic(ctx context.Context, merge *WalletMerge, confirmedBy *uuid.UUID) error {
+	args := m.Called(ctx, merge, confirmedBy)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateMerge(ctx context.Context, merge *WalletMerge) error {
+	args := m.Called(ctx, merge)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetMergeByID(ctx context.Context, id uuid.UUID) (*WalletMerge, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WalletMerge), args.Error(1)
+}
+
+func (m *MockRepository) GetOpenMergeBySource(ctx context.Context, sourceWalletID uuid.UUID) (*WalletMerge, error) {
+	args := m.Called(ctx, sourceWalletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WalletMerge), args.Error(1)
+}
+
+func (m *MockRepository) GetMergesByUser(ctx context.Context, userID uuid.UUID) ([]WalletMerge, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]WalletMerge), args.Error(1)
+}
+
+func (m *MockRepository) CancelMerge(ctx context.Context, merge *WalletMerge) error {
+	args := m.Called(ctx, merge)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateWalletWithTransaction(ctx context.Context, wallet *Wallet, tx *Transaction) error {
+	args := m.Called(ctx, wallet, tx)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetWalletByClaimCode(ctx context.Context, claimCodeHash string) (*Wallet, error) {
+	args := m.Called(ctx, claimCodeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Wallet), args.Error(1)
+}
+
+func (m *MockRepository) ClaimWallet(ctx context.Context, walletID, userID uuid.UUID, claimedAt time.Time) error {
+	args := m.Called(ctx, walletID, userID, claimedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetStandNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]string), args.Error(1)
+}
+
+func (m *MockRepository) GetProductNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]string), args.Error(1)
+}
+
+func (m *MockRepository) ThawDueWallets(ctx context.Context, now time.Time, scope clock.Scope) ([]Wallet, error) {
+	args := m.Called(ctx, now, scope)
+	return args.Get(0).([]Wallet), args.Error(1)
+}
+
+func (m *MockRepository) GetWalletHolder(ctx context.Context, walletID uuid.UUID) (*WalletHolder, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WalletHolder), args.Error(1)
+}
+
+func (m *MockRepository) SetActivityPush(ctx context.Context, wallet *Wallet) error {
+	args := m.Called(ctx, wallet)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListDigestFestivals(ctx context.Context, scope clock.Scope) ([]DigestFestival, error) {
+	args := m.Called(ctx, scope)
+	return args.Get(0).([]DigestFestival), args.Error(1)
+}
+
+func (m *MockRepository) ListDueDigestWallets(ctx context.Context, festivalID uuid.UUID, periodEnd time.Time) ([]DigestWallet, error) {
+	args := m.Called(ctx, festivalID, periodEnd)
+	return args.Get(0).([]DigestWallet), args.Error(1)
+}
+
+func (m *MockRepository) MarkDigestSent(ctx context.Context, walletID uuid.UUID, periodEnd time.Time) (bool, error) {
+	args := m.Called(ctx, walletID, periodEnd)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetDigestSpending(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]StandSpending, error) {
+	args := m.Called(ctx, walletID, from, to)
+	return args.Get(0).([]StandSpending), args.Error(1)
+}
+
+func (m *MockRepository) ReplaceWristbandAtomic(ctx context.Context, replacement *WristbandReplacement, oldUID string, claimCodeHash *string) (*Wallet, error) {
+	args := m.Called(ctx, replacement, oldUID, claimCodeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Wallet), args.Error(1)
+}
+
+func (m *MockRepository) GetWristbandReplacements(ctx context.Context, walletID uuid.UUID) ([]WristbandReplacement, error) {
+	args := m.Called(ctx, walletID)
+	return args.Get(0).([]WristbandReplacement), args.Error(1)
+}
+
+func (m *MockRepository) GetRevokedWristbands(ctx context.Context, festivalID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// RoundUpCollector rounds the purchases of opted-in wallets up to the next euro for the
+// charity of their festival; satisfied by donation.Service
+type RoundUpCollector interface {
+	// RoundUp returns how much a purchase of the wallet is rounded up by and the charity
+	// it goes to, zero when the wallet did not opt in
+	RoundUp(ctx context.Context, walletID uuid.UUID, amount int64) (int64, string, error)
+	// RecordRoundUp adds a round-up debited from the wallet to the donation ledger
+	RecordRoundUp(ctx context.Context, walletID, purchaseID, transactionID uuid.UUID, amount int64, charity string) error
+}
+
+// RoundUp is the donation a purchase was rounded up by, shown on its receipt
+type RoundUp struct {
+	TransactionID uuid.UUID `json:"transactionId"`
+	Amount        int64     `json:"amount"`
+	Charity       string    `json:"charity"`
+}
+
+// SetRoundUpCollector rounds up the purchases of the wallets opted in to donations
+func (s *Service) SetRoundUpCollector(collector RoundUpCollector) {
+	s.roundUps = collector
+}
+
+// collectRoundUp debits the round-up of a purchase with a donation transaction and
+// records it in the ledger. The purchase stands whatever happens here: a wallet left
+// short of the round-up, or a collector failing, only skips the donation. A round-up
+// debited but missing from the ledger is credited back, as it would never reach the
+// charity.
+func (s *Service) collectRoundUp(ctx context.Context, purchase *Transaction, amount int64) {
+	if s.roundUps == nil {
+		return
+	}
+
+	roundUp, charity, err := s.roundUps.RoundUp(ctx, purchase.WalletID, amount)
+	if err != nil {
+		log.Error().Err(err).Str("wallet_id", purchase.WalletID.String()).Msg("Failed to get purchase round-up")
+		return
+	}
+	if roundUp == 0 {
+		return
+	}
+
+	donation := &Transaction{
+		ID:        uuid.New(),
+		WalletID:  purchase.WalletID,
+		Type:      TransactionTypeDonation,
+		Reference: purchase.ID.String(),
+		StandID:   purchase.StandID,
+		StaffID:   purchase.StaffID,
+		Metadata: TransactionMeta{
+			Description: charity,
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.ProcessPayment(ctx, purchase.WalletID, roundUp, donation); err != nil {
+		log.Info().Err(err).Str("wallet_id", purchase.WalletID.String()).Msg("Purchase round-up skipped")
+		return
+	}
+	if err := s.roundUps.RecordRoundUp(ctx, purchase.WalletID, purchase.ID, donation.ID, roundUp, charity); err != nil {
+		log.Error().Err(err).Str("transaction_id", donation.ID.String()).Msg("Failed to record round-up donation, reversing it")
+		s.reverseRoundUp(ctx, donation, roundUp)
+		return
+	}
+
+	purchase.RoundUp = &RoundUp{TransactionID: donation.ID, Amount: roundUp, Charity: charity}
+}
+
+// reverseRoundUp credits back a round-up debited without being recorded in the ledger
+func (s *Service) reverseRoundUp(ctx context.Context, donation *Transaction, amount int64) {
+	reversal := &Transaction{
+		ID:        uuid.New(),
+		WalletID:  donation.WalletID,
+		Type:      TransactionTypeRefund,
+		Amount:    amount,
+		Reference: donation.ID.String(),
+		StandID:   donation.StandID,
+		StaffID:   donation.StaffID,
+		Metadata: TransactionMeta{
+			Description: "Round-up donation reversed",
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.RefundAtomic(ctx, donation.WalletID, amount, reversal, donation.ID); err != nil {
+		// Left for the nightly reconciliation to report
+		log.Error().Err(err).Str("transaction_id", donation.ID.String()).Msg("Failed to reverse unrecorded round-up donation")
+	}
+}
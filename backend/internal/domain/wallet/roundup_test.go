@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRoundUpCollector struct {
+	roundUp   int64
+	recordErr error
+	recorded  []uuid.UUID // Donation transactions
+}
+
+func (c *fakeRoundUpCollector) RoundUp(ctx context.Context, walletID uuid.UUID, amount int64) (int64, string, error) {
+	return c.roundUp, "Red Cross", nil
+}
+
+func (c *fakeRoundUpCollector) RecordRoundUp(ctx context.Context, walletID, purchaseID, transactionID uuid.UUID, amount int64, charity string) error {
+	if c.recordErr != nil {
+		return c.recordErr
+	}
+	c.recorded = append(c.recorded, transactionID)
+	return nil
+}
+
+func TestService_ProcessPayment_RoundUp(t *testing.T) {
+	walletID := uuid.New()
+	req := PaymentRequest{WalletID: walletID, Amount: 450, StandID: uuid.New()}
+	isDonation := mock.MatchedBy(func(tx *Transaction) bool { return tx.Type == TransactionTypeDonation })
+
+	setup := func(collector *fakeRoundUpCollector) (*MockRepository, *Service) {
+		mockRepo := NewMockRepository()
+		mockRepo.On("ProcessPayment", mock.Anything, walletID, int64(450), mock.AnythingOfType("*wallet.Transaction")).Return(nil).Once()
+		service := NewService(mockRepo, testSecretKey)
+		service.SetRoundUpCollector(collector)
+		return mockRepo, service
+	}
+
+	t.Run("recorded in the ledger", func(t *testing.T) {
+		collector := &fakeRoundUpCollector{roundUp: 50}
+		mockRepo, service := setup(collector)
+		mockRepo.On("ProcessPayment", mock.Anything, walletID, int64(50), isDonation).Return(nil).Once()
+
+		tx, err := service.ProcessPayment(context.Background(), req, uuid.New())
+		require.NoError(t, err)
+		require.NotNil(t, tx.RoundUp)
+		assert.Equal(t, int64(50), tx.RoundUp.Amount)
+		assert.Equal(t, []uuid.UUID{tx.RoundUp.TransactionID}, collector.recorded)
+		mockRepo.AssertNotCalled(t, "RefundAtomic", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ledger failure reverses the debit", func(t *testing.T) {
+		collector := &fakeRoundUpCollector{roundUp: 50, recordErr: errors.New("connection reset")}
+		mockRepo, service := setup(collector)
+		var donationID uuid.UUID
+		mockRepo.On("ProcessPayment", mock.Anything, walletID, int64(50), isDonation).
+			Run(func(args mock.Arguments) { donationID = args.Get(3).(*Transaction).ID }).
+			Return(nil).Once()
+		mockRepo.On("RefundAtomic", mock.Anything, walletID, int64(50), mock.AnythingOfType("*wallet.Transaction"), mock.AnythingOfType("uuid.UUID")).
+			Run(func(args mock.Arguments) {
+				reversal := args.Get(3).(*Transaction)
+				assert.Equal(t, TransactionTypeRefund, reversal.Type)
+				assert.Equal(t, int64(50), reversal.Amount)
+				assert.Equal(t, donationID.String(), reversal.Reference)
+				assert.Equal(t, donationID, args.Get(4))
+			}).
+			Return(nil).Once()
+
+		// The purchase stands, without a round-up on its receipt
+		tx, err := service.ProcessPayment(context.Background(), req, uuid.New())
+		require.NoError(t, err)
+		assert.Nil(t, tx.RoundUp)
+		assert.Empty(t, collector.recorded)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("wallet short of the round-up", func(t *testing.T) {
+		collector := &fakeRoundUpCollector{roundUp: 50}
+		mockRepo, service := setup(collector)
+		mockRepo.On("ProcessPayment", mock.Anything, walletID, int64(50), isDonation).Return(errors.New("insufficient balance")).Once()
+
+		tx, err := service.ProcessPayment(context.Background(), req, uuid.New())
+		require.NoError(t, err)
+		assert.Nil(t, tx.RoundUp)
+		assert.Empty(t, collector.recorded)
+		mockRepo.AssertExpectations(t)
+	})
+}
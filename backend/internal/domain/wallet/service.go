@@ -3,51 +3,80 @@ package wallet
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/clock"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 )
 
-type Service struct {
-	repo            Repository
-	secretKey       []byte // For QR code signing
-	qrExpirySeconds int64  // QR code expiry time in seconds
+// ChangeSequencer counts the wallet changes the POS terminals compare to know their
+// cached balances are stale; satisfied by posdevice.Sequencer
+type ChangeSequencer interface {
+	WalletChanged(ctx context.Context, walletID uuid.UUID)
 }
 
-// DefaultQRExpirySeconds is the default QR code expiry time (24 hours)
-// This is appropriate for ticket-based QR codes that need to remain valid for the event duration
-const DefaultQRExpirySeconds = 86400 // 24 hours
+type Service struct {
+	repo     Repository
+	secrets  *security.Keyring // For QR code signing and claim code hashing
+	qrPeriod time.Duration     // Rotation period of wallet QR codes
+
+	statementRenderer StatementRenderer
+	statementBranding StatementBrandingProvider
+	statementMailer   StatementMailer
+
+	freezeNotifier   FreezeNotifier
+	activityNotifier ActivityNotifier
+
+	credentialBroadcaster CredentialBroadcaster
+	auditLogger           AuditLogger
+	sequencer             ChangeSequencer
+	roundUps              RoundUpCollector
+
+	clock clock.Clock
+}
 
 func NewService(repo Repository, secretKey string) *Service {
 	return &Service{
-		repo:            repo,
-		secretKey:       []byte(secretKey),
-		qrExpirySeconds: DefaultQRExpirySeconds,
+		repo:     repo,
+		secrets:  security.NewKeyring(secretKey, nil, 0),
+		qrPeriod: DefaultQRPeriod,
+		clock:    clock.Wall{},
 	}
 }
 
-// NewServiceWithConfig creates a new service with configurable QR expiry time
-func NewServiceWithConfig(repo Repository, secretKey string, qrExpirySeconds int) *Service {
-	expiry := int64(qrExpirySeconds)
-	if expiry <= 0 {
-		expiry = DefaultQRExpirySeconds
-	}
-	return &Service{
-		repo:            repo,
-		secretKey:       []byte(secretKey),
-		qrExpirySeconds: expiry,
+// SetQRPeriod changes how often wallet QR codes rotate
+func (s *Service) SetQRPeriod(period time.Duration) {
+	if period >= time.Second {
+		s.qrPeriod = period
 	}
 }
 
-// SetQRExpirySeconds allows updating the QR expiry time
-func (s *Service) SetQRExpirySeconds(seconds int) {
-	if seconds > 0 {
-		s.qrExpirySeconds = int64(seconds)
+// SetKeyring replaces the secret given to the constructor with a keyring that can be
+// rotated at runtime
+func (s *Service) SetKeyring(keyring *security.Keyring) {
+	s.secrets = keyring
+}
+
+// SetChangeSequencer counts the balance and status changes of the wallets for the POS
+// terminals
+func (s *Service) SetChangeSequencer(sequencer ChangeSequencer) {
+	s.sequencer = sequencer
+}
+
+// walletChanged reports wallets whose balance or status changed to the sequencer
+func (s *Service) walletChanged(ctx context.Context, walletIDs ...uuid.UUID) {
+	if s.sequencer == nil {
+		return
+	}
+	for _, walletID := range walletIDs {
+		s.sequencer.WalletChanged(ctx, walletID)
 	}
 }
 
@@ -65,7 +94,7 @@ func (s *Service) GetOrCreateWallet(ctx context.Context, userID, festivalID uuid
 	// Create new wallet
 	wallet = &Wallet{
 		ID:         uuid.New(),
-		UserID:     userID,
+		UserID:     &userID,
 		FestivalID: festivalID,
 		Balance:    0,
 		Status:     WalletStatusActive,
@@ -80,6 +109,159 @@ func (s *Service) GetOrCreateWallet(ctx context.Context, userID, festivalID uuid
 	return wallet, nil
 }
 
+// claimCodeAlphabet leaves out 0, O, 1 and I, which are easily confused on a printed card
+const claimCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// claimCodeLength is the number of characters of a claim code, printed in groups of 4
+const claimCodeLength = 12
+
+// CreateAnonymousWallet creates a wallet without a user, funded with the cash paid at
+// the entrance. The returned claim code is printed on the wristband card; only its
+// HMAC is stored, so it cannot be retrieved later.
+func (s *Service) CreateAnonymousWallet(ctx context.Context, req CreateAnonymousWalletRequest, staffID *uuid.UUID) (*Wallet, *Transaction, string, error) {
+	claimCode, err := generateClaimCode()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to generate claim code: %w", err)
+	}
+	claimCodeHash := s.hashClaimCode(claimCode)
+
+	now := time.Now()
+	wallet := &Wallet{
+		ID:            uuid.New(),
+		FestivalID:    req.FestivalID,
+		Status:        WalletStatusActive,
+		ClaimCodeHash: &claimCodeHash,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	tx := &Transaction{
+		ID:       uuid.New(),
+		WalletID: wallet.ID,
+		Type:     TransactionTypeCashIn,
+		Amount:   req.Amount,
+		StaffID:  staffID,
+		Metadata: TransactionMeta{
+			PaymentMethod: "cash",
+			Description:   "Anonymous wallet sold at the entrance",
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: now,
+	}
+
+	if err := s.repo.CreateWalletWithTransaction(ctx, wallet, tx); err != nil {
+		return nil, nil, "", err
+	}
+
+	return wallet, tx, claimCode, nil
+}
+
+// ClaimWallet attaches the anonymous wallet of a claim code to a user, which lets
+// the user see it in the app and request refunds of its balance. If the user
+// already has a wallet for the festival, the anonymous wallet is merged into it.
+// Claiming a wallet the user already claimed returns it.
+func (s *Service) ClaimWallet(ctx context.Context, userID uuid.UUID, req ClaimWalletRequest) (*Wallet, error) {
+	wallet, err := s.getWalletByClaimCode(ctx, req.ClaimCode)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, ErrInvalidClaimCode
+	}
+
+	// A claimed wallet may since have been merged into another wallet of its owner
+	if wallet.MergedIntoID != nil {
+		target, err := s.GetWallet(ctx, *wallet.MergedIntoID)
+		if err != nil {
+			return nil, err
+		}
+		if !target.IsOwnedBy(userID) {
+			return nil, ErrWalletAlreadyClaimed
+		}
+		return target, nil
+	}
+	if !wallet.IsAnonymous() {
+		if !wallet.IsOwnedBy(userID) {
+			return nil, ErrWalletAlreadyClaimed
+		}
+		return wallet, nil
+	}
+
+	existing, err := s.repo.GetWalletByUserAndFestival(ctx, userID, wallet.FestivalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		now := time.Now()
+		if err := s.repo.ClaimWallet(ctx, wallet.ID, userID, now); err != nil {
+			return nil, err
+		}
+		wallet.UserID = &userID
+		wallet.ClaimedAt = &now
+		wallet.UpdatedAt = now
+		return wallet, nil
+	}
+
+	// The user already has a wallet for the festival: the claim code proves the
+	// user holds the card, so the merge is confirmed right away
+	merge, err := s.InitiateMerge(ctx, InitiateMergeRequest{
+		SourceWalletID: wallet.ID,
+		TargetWalletID: existing.ID,
+	}, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ConfirmMerge(ctx, merge.ID, &userID, &userID); err != nil {
+		return nil, err
+	}
+
+	return s.GetWallet(ctx, existing.ID)
+}
+
+// generateClaimCode returns a random claim code formatted as XXXX-XXXX-XXXX
+func generateClaimCode() (string, error) {
+	buf := make([]byte, claimCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, b := range buf {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		// The alphabet has 32 characters, so every byte maps to one without bias
+		code.WriteByte(claimCodeAlphabet[int(b)%len(claimCodeAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// getWalletByClaimCode looks a claim code up under every accepted secret, since the
+// cards printed before a rotation were hashed with the previous one
+func (s *Service) getWalletByClaimCode(ctx context.Context, claimCode string) (*Wallet, error) {
+	for _, secret := range s.secrets.Accepted() {
+		wallet, err := s.repo.GetWalletByClaimCode(ctx, hashClaimCodeWith(secret, claimCode))
+		if err != nil || wallet != nil {
+			return wallet, err
+		}
+	}
+	return nil, nil
+}
+
+// hashClaimCode returns the HMAC of a claim code under the current secret
+func (s *Service) hashClaimCode(claimCode string) string {
+	return hashClaimCodeWith(s.secrets.Current(), claimCode)
+}
+
+// hashClaimCodeWith returns the HMAC of a claim code, ignoring case, spaces and dashes
+func hashClaimCodeWith(secret []byte, claimCode string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(claimCode))
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte("claim:" + normalized))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // GetWallet gets a wallet by ID
 func (s *Service) GetWallet(ctx context.Context, id uuid.UUID) (*Wallet, error) {
 	wallet, err := s.repo.GetWalletByID(ctx, id)
@@ -122,11 +304,14 @@ func (s *Service) TopUp(ctx context.Context, walletID uuid.UUID, req TopUpReques
 	if err := s.repo.TopUpAtomic(ctx, walletID, req.Amount, tx); err != nil {
 		return nil, err
 	}
+	s.walletChanged(ctx, walletID)
+	s.notifyActivity(ctx, tx)
 
 	return tx, nil
 }
 
-// ProcessPayment processes a payment at a stand
+// ProcessPayment processes a payment at a stand. A wallet opted in to round-up
+// donations is then debited the round-up of the purchase, shown on its receipt.
 func (s *Service) ProcessPayment(ctx context.Context, req PaymentRequest, staffID uuid.UUID) (*Transaction, error) {
 	tx := &Transaction{
 		ID:       uuid.New(),
@@ -144,6 +329,9 @@ func (s *Service) ProcessPayment(ctx context.Context, req PaymentRequest, staffI
 	if err := s.repo.ProcessPayment(ctx, req.WalletID, req.Amount, tx); err != nil {
 		return nil, err
 	}
+	s.collectRoundUp(ctx, tx, req.Amount)
+	s.walletChanged(ctx, req.WalletID)
+	s.notifyActivity(ctx, tx)
 
 	return tx, nil
 }
@@ -189,10 +377,72 @@ func (s *Service) RefundTransaction(ctx context.Context, transactionID uuid.UUID
 	if err := s.repo.RefundAtomic(ctx, originalTx.WalletID, refundAmount, refundTx, transactionID); err != nil {
 		return nil, err
 	}
+	s.walletChanged(ctx, originalTx.WalletID)
 
 	return refundTx, nil
 }
 
+// ReplacePayment refunds a purchase and charges the corrected amount in a single
+// atomic operation. It is used when a paid order is corrected with different items.
+func (s *Service) ReplacePayment(ctx context.Context, transactionID uuid.UUID, req PaymentRequest, reason string, staffID uuid.UUID) (*Transaction, *Transaction, error) {
+	originalTx, err := s.repo.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if originalTx == nil {
+		return nil, nil, errors.ErrNotFound
+	}
+
+	if originalTx.Status != TransactionStatusCompleted {
+		return nil, nil, fmt.Errorf("transaction cannot be refunded")
+	}
+
+	if originalTx.Type != TransactionTypePurchase {
+		return nil, nil, fmt.Errorf("only purchases can be refunded")
+	}
+
+	if originalTx.WalletID != req.WalletID {
+		return nil, nil, fmt.Errorf("replacement must be charged to the original wallet")
+	}
+
+	now := time.Now()
+	refundTx := &Transaction{
+		ID:        uuid.New(),
+		WalletID:  originalTx.WalletID,
+		Type:      TransactionTypeRefund,
+		Amount:    -originalTx.Amount,
+		Reference: transactionID.String(),
+		StandID:   originalTx.StandID,
+		StaffID:   &staffID,
+		Metadata: TransactionMeta{
+			Description: reason,
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: now,
+	}
+	purchaseTx := &Transaction{
+		ID:        uuid.New(),
+		WalletID:  req.WalletID,
+		Type:      TransactionTypePurchase,
+		Reference: transactionID.String(),
+		StandID:   &req.StandID,
+		StaffID:   &staffID,
+		Metadata: TransactionMeta{
+			Description: reason,
+			ProductIDs:  req.ProductIDs,
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: now,
+	}
+
+	if err := s.repo.ReplacePaymentAtomic(ctx, originalTx.WalletID, refundTx, purchaseTx, req.Amount, transactionID); err != nil {
+		return nil, nil, err
+	}
+	s.walletChanged(ctx, originalTx.WalletID)
+
+	return refundTx, purchaseTx, nil
+}
+
 // GetTransactions gets transactions for a wallet
 func (s *Service) GetTransactions(ctx context.Context, walletID uuid.UUID, page, perPage int) ([]Transaction, int64, error) {
 	if page < 1 {
@@ -206,145 +456,246 @@ func (s *Service) GetTransactions(ctx context.Context, walletID uuid.UUID, page,
 	return s.repo.GetTransactionsByWallet(ctx, walletID, offset, perPage)
 }
 
-// QRCodePayload represents the data encoded in a wallet QR code
-type QRCodePayload struct {
-	WalletID   uuid.UUID `json:"w"`
-	FestivalID uuid.UUID `json:"f"`
-	Timestamp  int64     `json:"t"`
-	Signature  string    `json:"s"`
+// TopUpFromPayment adds funds to a wallet from a successful Stripe payment
+// This method is called by the payment service when a payment is confirmed
+// Uses atomic database transaction to ensure consistency
+func (s *Service) TopUpFromPayment(ctx context.Context, walletID uuid.UUID, amount int64, reference string) error {
+	// Create transaction (balances will be set atomically by repository)
+	tx := &Transaction{
+		ID:        uuid.New(),
+		WalletID:  walletID,
+		Type:      TransactionTypeTopUp,
+		Amount:    amount,
+		Reference: reference,
+		Metadata: TransactionMeta{
+			PaymentMethod: "stripe",
+			Description:   "Stripe payment: " + reference,
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: time.Now(),
+	}
+
+	// Execute atomic top-up operation
+	if err := s.repo.TopUpAtomic(ctx, walletID, amount, tx); err != nil {
+		return err
+	}
+	s.walletChanged(ctx, walletID)
+	s.notifyActivity(ctx, tx)
+	return nil
 }
 
-// GenerateQRPayload generates a signed QR code payload for a wallet
-func (s *Service) GenerateQRPayload(ctx context.Context, walletID uuid.UUID) (string, error) {
-	wallet, err := s.repo.GetWalletByID(ctx, walletID)
-	if err != nil {
-		return "", err
+// TopUpFromTransfer adds funds to a wallet from a bank transfer matched to a top-up
+// order. The transaction ID is chosen by the order, so that a credit retried after a
+// failure returns ErrAdjustmentApplied instead of applying it twice.
+func (s *Service) TopUpFromTransfer(ctx context.Context, walletID, transactionID uuid.UUID, amount int64, reference string) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, ErrAdjustmentAmount
 	}
-	if wallet == nil {
-		return "", errors.ErrNotFound
+
+	tx := &Transaction{
+		ID:        transactionID,
+		WalletID:  walletID,
+		Type:      TransactionTypeTopUp,
+		Amount:    amount,
+		Reference: reference,
+		Metadata: TransactionMeta{
+			PaymentMethod: "transfer",
+			Description:   "Bank transfer: " + reference,
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: time.Now(),
 	}
 
-	payload := QRCodePayload{
-		WalletID:   wallet.ID,
-		FestivalID: wallet.FestivalID,
-		Timestamp:  time.Now().Unix(),
+	if err := s.repo.AdjustAtomic(ctx, walletID, tx); err != nil {
+		return nil, err
 	}
+	s.walletChanged(ctx, walletID)
+	s.notifyActivity(ctx, tx)
+	return tx, nil
+}
 
-	// Generate signature
-	signature := s.signPayload(payload)
-	payload.Signature = signature
+// Adjust credits or debits a wallet on behalf of the organizer, e.g. to compensate the
+// attendees of a cancelled show. Retrying an adjustment with the same transaction ID
+// returns ErrAdjustmentApplied instead of applying it twice.
+func (s *Service) Adjust(ctx context.Context, walletID uuid.UUID, req AdjustRequest, staffID *uuid.UUID) (*Transaction, error) {
+	if req.Amount == 0 {
+		return nil, ErrAdjustmentAmount
+	}
+	if req.TransactionID == uuid.Nil {
+		req.TransactionID = uuid.New()
+	}
 
-	// Encode to JSON
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal QR payload: %w", err)
+	tx := &Transaction{
+		ID:        req.TransactionID,
+		WalletID:  walletID,
+		Type:      TransactionTypeAdjustment,
+		Amount:    req.Amount,
+		Reference: req.Reference,
+		StaffID:   staffID,
+		Metadata: TransactionMeta{
+			Description: req.Reason,
+		},
+		Status:    TransactionStatusCompleted,
+		CreatedAt: time.Now(),
 	}
 
-	// Base64 encode for QR code
-	return base64.StdEncoding.EncodeToString(data), nil
+	if err := s.repo.AdjustAtomic(ctx, walletID, tx); err != nil {
+		return nil, err
+	}
+	s.walletChanged(ctx, walletID)
+	return tx, nil
 }
 
-// ValidateQRPayload validates a QR code payload
-func (s *Service) ValidateQRPayload(ctx context.Context, encoded string) (*QRCodePayload, error) {
-	// Decode base64
-	data, err := base64.StdEncoding.DecodeString(encoded)
+// InitiateMerge opens the merge of a source wallet into a target wallet of the same
+// festival, e.g. when staff find that an attendee's wristband wallet and account
+// wallet are two wallets. Nothing moves until the merge is confirmed. Initiating a
+// merge that is already pending or completed for the same wallets returns it.
+func (s *Service) InitiateMerge(ctx context.Context, req InitiateMergeRequest, initiatedBy *uuid.UUID) (*WalletMerge, error) {
+	if req.SourceWalletID == req.TargetWalletID {
+		return nil, ErrMergeSameWallet
+	}
+
+	source, err := s.GetWallet(ctx, req.SourceWalletID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid QR code format")
+		return nil, err
+	}
+	target, err := s.GetWallet(ctx, req.TargetWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if source.FestivalID != target.FestivalID {
+		return nil, ErrMergeFestivalMismatch
 	}
 
-	// Parse JSON
-	var payload QRCodePayload
-	if err := json.Unmarshal(data, &payload); err != nil {
-		return nil, fmt.Errorf("invalid QR code data")
+	existing, err := s.repo.GetOpenMergeBySource(ctx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existingMerge(existing, target.ID)
 	}
 
-	// Check timestamp using configurable expiry time
-	// Default is 24 hours (86400 seconds), suitable for ticket QR codes
-	// that need to remain valid for the duration of an event
-	if time.Now().Unix()-payload.Timestamp > s.qrExpirySeconds {
-		return nil, fmt.Errorf("QR code expired")
+	if source.MergedIntoID != nil {
+		return nil, ErrWalletAlreadyMerged
+	}
+	if target.IsAnonymous() {
+		return nil, ErrMergeTargetAnonymous
+	}
+	if source.Status != WalletStatusActive || target.Status != WalletStatusActive {
+		return nil, ErrMergeWalletNotActive
 	}
 
-	// Verify signature
-	expectedSig := s.signPayload(QRCodePayload{
-		WalletID:   payload.WalletID,
-		FestivalID: payload.FestivalID,
-		Timestamp:  payload.Timestamp,
-	})
+	now := time.Now()
+	merge := &WalletMerge{
+		ID:             uuid.New(),
+		FestivalID:     source.FestivalID,
+		SourceWalletID: source.ID,
+		TargetWalletID: target.ID,
+		SourceUserID:   source.UserID,
+		TargetUserID:   *target.UserID,
+		Status:         MergeStatusPending,
+		Amount:         source.Balance,
+		InitiatedBy:    initiatedBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
 
-	if payload.Signature != expectedSig {
-		return nil, fmt.Errorf("invalid QR code signature")
+	if err := s.repo.CreateMerge(ctx, merge); err != nil {
+		// A concurrent request opened a merge of the same source wallet first
+		if errors.Is(err, ErrMergeAlreadyPending) {
+			existing, getErr := s.repo.GetOpenMergeBySource(ctx, source.ID)
+			if getErr == nil && existing != nil {
+				return existingMerge(existing, target.ID)
+			}
+		}
+		return nil, err
 	}
 
-	return &payload, nil
+	return merge, nil
 }
 
-func (s *Service) signPayload(payload QRCodePayload) string {
-	data := fmt.Sprintf("%s:%s:%d", payload.WalletID, payload.FestivalID, payload.Timestamp)
-	h := hmac.New(sha256.New, s.secretKey)
-	h.Write([]byte(data))
-	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+// existingMerge returns the open merge of a source wallet if it goes to targetID
+func existingMerge(merge *WalletMerge, targetID uuid.UUID) (*WalletMerge, error) {
+	if merge.TargetWalletID == targetID {
+		return merge, nil
+	}
+	if merge.Status == MergeStatusCompleted {
+		return nil, ErrWalletAlreadyMerged
+	}
+	return nil, ErrMergeAlreadyPending
 }
 
-// FreezeWallet freezes a wallet (admin action)
-func (s *Service) FreezeWallet(ctx context.Context, walletID uuid.UUID) (*Wallet, error) {
-	wallet, err := s.repo.GetWalletByID(ctx, walletID)
+// GetMerge gets a merge by ID. When userID is set, only merges of the user's
+// wallets are returned.
+func (s *Service) GetMerge(ctx context.Context, id uuid.UUID, userID *uuid.UUID) (*WalletMerge, error) {
+	merge, err := s.repo.GetMergeByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	if wallet == nil {
+	if merge == nil {
 		return nil, errors.ErrNotFound
 	}
-
-	wallet.Status = WalletStatusFrozen
-	wallet.UpdatedAt = time.Now()
-
-	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
-		return nil, fmt.Errorf("failed to freeze wallet: %w", err)
+	if userID != nil && merge.TargetUserID != *userID && (merge.SourceUserID == nil || *merge.SourceUserID != *userID) {
+		return nil, errors.ErrNotFound
 	}
+	return merge, nil
+}
 
-	return wallet, nil
+// GetUserMerges gets the merges into or out of a user's wallets
+func (s *Service) GetUserMerges(ctx context.Context, userID uuid.UUID) ([]WalletMerge, error) {
+	return s.repo.GetMergesByUser(ctx, userID)
 }
 
-// UnfreezeWallet unfreezes a wallet (admin action)
-func (s *Service) UnfreezeWallet(ctx context.Context, walletID uuid.UUID) (*Wallet, error) {
-	wallet, err := s.repo.GetWalletByID(ctx, walletID)
+// ConfirmMerge moves the balance, transaction history and wristbands of the source
+// wallet to the target wallet and closes the source wallet. When userID is set the
+// merge must go to the user's wallet. Confirming a completed merge returns it as is.
+func (s *Service) ConfirmMerge(ctx context.Context, id uuid.UUID, confirmedBy *uuid.UUID, userID *uuid.UUID) (*WalletMerge, error) {
+	merge, err := s.GetMerge(ctx, id, userID)
 	if err != nil {
 		return nil, err
 	}
-	if wallet == nil {
-		return nil, errors.ErrNotFound
+	if userID != nil && merge.TargetUserID != *userID {
+		return nil, errors.ErrForbidden
 	}
 
-	wallet.Status = WalletStatusActive
-	wallet.UpdatedAt = time.Now()
+	switch merge.Status {
+	case MergeStatusCompleted:
+		return merge, nil
+	case MergeStatusCancelled:
+		return nil, ErrMergeNotPending
+	}
 
-	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
-		return nil, fmt.Errorf("failed to unfreeze wallet: %w", err)
+	if err := s.repo.MergeWalletsAtomic(ctx, merge, confirmedBy); err != nil {
+		return nil, err
 	}
+	s.walletChanged(ctx, merge.SourceWalletID, merge.TargetWalletID)
 
-	return wallet, nil
+	return merge, nil
 }
 
-// TopUpFromPayment adds funds to a wallet from a successful Stripe payment
-// This method is called by the payment service when a payment is confirmed
-// Uses atomic database transaction to ensure consistency
-func (s *Service) TopUpFromPayment(ctx context.Context, walletID uuid.UUID, amount int64, reference string) error {
-	// Create transaction (balances will be set atomically by repository)
-	tx := &Transaction{
-		ID:        uuid.New(),
-		WalletID:  walletID,
-		Type:      TransactionTypeTopUp,
-		Amount:    amount,
-		Reference: reference,
-		Metadata: TransactionMeta{
-			PaymentMethod: "stripe",
-			Description:   "Stripe payment: " + reference,
-		},
-		Status:    TransactionStatusCompleted,
-		CreatedAt: time.Now(),
+// CancelMerge cancels a pending merge. When userID is set the merge must involve
+// one of the user's wallets. Cancelling a cancelled merge returns it as is.
+func (s *Service) CancelMerge(ctx context.Context, id uuid.UUID, cancelledBy *uuid.UUID, userID *uuid.UUID) (*WalletMerge, error) {
+	merge, err := s.GetMerge(ctx, id, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Execute atomic top-up operation
-	return s.repo.TopUpAtomic(ctx, walletID, amount, tx)
+	switch merge.Status {
+	case MergeStatusCancelled:
+		return merge, nil
+	case MergeStatusCompleted:
+		return nil, ErrMergeNotPending
+	}
+
+	now := time.Now()
+	merge.CancelledBy = cancelledBy
+	merge.CancelledAt = &now
+	merge.UpdatedAt = now
+
+	if err := s.repo.CancelMerge(ctx, merge); err != nil {
+		return nil, err
+	}
+
+	return merge, nil
 }
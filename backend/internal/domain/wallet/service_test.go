@@ -5,18 +5,25 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // testSecretKey is a 32+ character secret key used ONLY for unit tests.
 // DO NOT use this in production. Production secrets must be set via environment variables.
 const testSecretKey = "TEST_ONLY_not_for_production_use_32chars_min_secret_key_abc123"
 
+func uuidPtr(id uuid.UUID) *uuid.UUID {
+	return &id
+}
+
 // TestService_GetOrCreateWallet tests the GetOrCreateWallet method
 func TestService_GetOrCreateWallet(t *testing.T) {
 	tests := []struct {
@@ -34,7 +41,7 @@ func TestService_GetOrCreateWallet(t *testing.T) {
 			setupMock: func(m *MockRepository, userID, festivalID uuid.UUID) {
 				existingWallet := &Wallet{
 					ID:         uuid.New(),
-					UserID:     userID,
+					UserID:     &userID,
 					FestivalID: festivalID,
 					Balance:    5000,
 					Status:     WalletStatusActive,
@@ -83,7 +90,7 @@ func TestService_GetOrCreateWallet(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, wallet)
-				assert.Equal(t, tt.userID, wallet.UserID)
+				assert.Equal(t, &tt.userID, wallet.UserID)
 				assert.Equal(t, tt.festivalID, wallet.FestivalID)
 
 				if tt.wantNew {
@@ -120,7 +127,7 @@ func TestService_TopUp(t *testing.T) {
 			setupMock: func(m *MockRepository, walletID uuid.UUID) {
 				wallet := &Wallet{
 					ID:        walletID,
-					UserID:    uuid.New(),
+					UserID:    uuidPtr(uuid.New()),
 					Balance:   5000,
 					Status:    WalletStatusActive,
 					CreatedAt: time.Now(),
@@ -149,7 +156,7 @@ func TestService_TopUp(t *testing.T) {
 			setupMock: func(m *MockRepository, walletID uuid.UUID) {
 				wallet := &Wallet{
 					ID:        walletID,
-					UserID:    uuid.New(),
+					UserID:    uuidPtr(uuid.New()),
 					Balance:   0,
 					Status:    WalletStatusActive,
 					CreatedAt: time.Now(),
@@ -176,7 +183,7 @@ func TestService_TopUp(t *testing.T) {
 			setupMock: func(m *MockRepository, walletID uuid.UUID) {
 				wallet := &Wallet{
 					ID:        walletID,
-					UserID:    uuid.New(),
+					UserID:    uuidPtr(uuid.New()),
 					Balance:   1000,
 					Status:    WalletStatusFrozen,
 					CreatedAt: time.Now(),
@@ -317,7 +324,7 @@ func TestService_QRPayload(t *testing.T) {
 
 	wallet := &Wallet{
 		ID:         walletID,
-		UserID:     uuid.New(),
+		UserID:     uuidPtr(uuid.New()),
 		FestivalID: festivalID,
 		Balance:    5000,
 		Status:     WalletStatusActive,
@@ -394,6 +401,173 @@ func TestService_QRPayload_InvalidSignature(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid QR code signature")
 }
 
+// TestService_QRPayload_SecretRotation tests that QR codes signed before a rotation
+// stay valid during the overlap window only
+func TestService_QRPayload_SecretRotation(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(&Wallet{ID: walletID, FestivalID: uuid.New(), Status: WalletStatusActive}, nil)
+
+	keyring := security.NewKeyring(testSecretKey, nil, time.Hour)
+	service := NewService(mockRepo, testSecretKey)
+	service.SetKeyring(keyring)
+
+	encoded, err := service.GenerateQRPayload(context.Background(), walletID)
+	assert.NoError(t, err)
+
+	keyring.Rotate("TEST_ONLY_rotated_secret_key_for_unit_tests_32chars_min", nil)
+	_, err = service.ValidateQRPayload(context.Background(), encoded)
+	assert.NoError(t, err, "previous secret is accepted during the overlap window")
+
+	keyring.Rotate("TEST_ONLY_rotated_secret_key_for_unit_tests_32chars_min", []string{})
+	_, err = service.ValidateQRPayload(context.Background(), encoded)
+	assert.NoError(t, err, "reloading the same secrets keeps the overlap window")
+
+	assert.True(t, keyring.Revoke(security.Fingerprint([]byte(testSecretKey))))
+	_, err = service.ValidateQRPayload(context.Background(), encoded)
+	assert.Error(t, err)
+}
+
+// TestService_QRPayload_Rotation tests that codes are refused outside the steps around
+// the current one
+func TestService_QRPayload_Rotation(t *testing.T) {
+	service := NewService(nil, testSecretKey)
+	period := int64(DefaultQRPeriod / time.Second)
+
+	sign := func(at time.Time) string {
+		payload := QRCodePayload{
+			WalletID:   uuid.New(),
+			FestivalID: uuid.New(),
+			Timestamp:  at.Unix() / period * period,
+		}
+		payload.Signature = service.signPayload(payload)
+		data, _ := json.Marshal(payload)
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	_, err := service.ValidateQRPayload(context.Background(), sign(time.Now().Add(-3*DefaultQRPeriod)))
+	assert.ErrorIs(t, err, ErrQRCodeExpired)
+
+	_, err = service.ValidateQRPayload(context.Background(), sign(time.Now().Add(3*DefaultQRPeriod)))
+	assert.ErrorIs(t, err, ErrQRCodeNotYetValid)
+
+	assert.True(t, service.QRCodeExpiresAt(time.Now()).After(time.Now().Add(DefaultQRPeriod)))
+}
+
+// TestService_QRMaterial tests that codes signed by the app with its QR material are
+// accepted until the material is rotated
+func TestService_QRMaterial(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	wallet := &Wallet{ID: walletID, UserID: uuidPtr(uuid.New()), FestivalID: uuid.New(), Status: WalletStatusActive}
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(wallet, nil)
+	mockRepo.On("UpdateWallet", mock.Anything, wallet).Return(nil)
+
+	service := NewService(mockRepo, testSecretKey)
+
+	material, err := service.GetQRMaterial(context.Background(), walletID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, material.Period)
+
+	// Sign a code the way the app does
+	seed, err := base64.StdEncoding.DecodeString(material.Seed)
+	require.NoError(t, err)
+	payload := QRCodePayload{
+		WalletID:   material.WalletID,
+		FestivalID: material.FestivalID,
+		Generation: material.Generation,
+		Timestamp:  time.Now().Unix() / int64(material.Period) * int64(material.Period),
+	}
+	payload.Signature = signQR(seed, payload)
+	data, _ := json.Marshal(payload)
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	_, err = service.ValidateQRPayload(context.Background(), encoded)
+	assert.NoError(t, err)
+
+	rotated, err := service.RotateQRMaterial(context.Background(), walletID)
+	require.NoError(t, err)
+	assert.Equal(t, material.Generation+1, rotated.Generation)
+	assert.NotEqual(t, material.Seed, rotated.Seed)
+
+	_, err = service.ValidateQRPayload(context.Background(), encoded)
+	assert.ErrorIs(t, err, ErrQRCodeRevoked)
+
+	wallet.Status = WalletStatusFrozen
+	_, err = service.GetQRMaterial(context.Background(), walletID)
+	assert.ErrorIs(t, err, ErrQRWalletNotActive)
+}
+
+// TestService_QROfflineSpec tests that POS devices can verify codes with the festival
+// keys of the offline spec, and get the revoked wallets
+func TestService_QROfflineSpec(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	festivalID := uuid.New()
+	frozenID := uuid.New()
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).
+		Return(&Wallet{ID: walletID, FestivalID: festivalID, Status: WalletStatusActive, QRGeneration: 2}, nil)
+	mockRepo.On("GetQRRevocations", mock.Anything, festivalID).Return([]Wallet{
+		{ID: walletID, Status: WalletStatusActive, QRGeneration: 2},
+		{ID: frozenID, Status: WalletStatusFrozen, QRGeneration: 1, FreezeReason: FreezeReasonLostWristband},
+	}, nil)
+	mockRepo.On("GetRevokedWristbands", mock.Anything, festivalID).Return([]string{"04A1B2C3D4E5F6"}, nil)
+
+	service := NewService(mockRepo, "TEST_ONLY_rotated_secret_key_for_unit_tests_32chars_min")
+	service.SetKeyring(security.NewKeyring("TEST_ONLY_rotated_secret_key_for_unit_tests_32chars_min",
+		[]string{testSecretKey}, time.Hour))
+
+	encoded, err := service.GenerateQRPayload(context.Background(), walletID)
+	require.NoError(t, err)
+	data, _ := base64.StdEncoding.DecodeString(encoded)
+	var payload QRCodePayload
+	require.NoError(t, json.Unmarshal(data, &payload))
+
+	spec, err := service.GetQROfflineSpec(context.Background(), festivalID)
+	require.NoError(t, err)
+	require.Len(t, spec.Keys, 2)
+	assert.True(t, spec.Keys[0].Current)
+	assert.NotNil(t, spec.Keys[1].AcceptUntil)
+	assert.Equal(t, []QRRevocation{
+		{WalletID: walletID, MinGeneration: 2},
+		{WalletID: frozenID, MinGeneration: 1, Blocked: true, Reason: FreezeReasonLostWristband},
+	}, spec.Revocations)
+	assert.Equal(t, []string{"04A1B2C3D4E5F6"}, spec.RevokedWristbands)
+
+	// Verify the code the way a POS device does
+	key, err := base64.StdEncoding.DecodeString(spec.Keys[0].Key)
+	require.NoError(t, err)
+	signed := payload
+	signed.Signature = ""
+	assert.Equal(t, payload.Signature, signQR(qrWalletSeed(key, payload.WalletID, payload.Generation), signed))
+	assert.Equal(t, 2, payload.Generation)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestService_ClaimWallet_PreviousSecret tests that claim codes printed before a
+// rotation are found under the previous secret
+func TestService_ClaimWallet_PreviousSecret(t *testing.T) {
+	const claimCode = "ABCD-EFGH-JKLM"
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, testSecretKey)
+	oldHash := service.hashClaimCode(claimCode)
+
+	service.SetKeyring(security.NewKeyring("TEST_ONLY_rotated_secret_key_for_unit_tests_32chars_min",
+		[]string{testSecretKey}, time.Hour))
+
+	userID := uuid.New()
+	claimed := &Wallet{ID: uuid.New(), UserID: &userID, FestivalID: uuid.New(), Status: WalletStatusActive}
+	mockRepo.On("GetWalletByClaimCode", mock.Anything, service.hashClaimCode(claimCode)).Return(nil, nil)
+	mockRepo.On("GetWalletByClaimCode", mock.Anything, oldHash).Return(claimed, nil)
+
+	wallet, err := service.ClaimWallet(context.Background(), userID, ClaimWalletRequest{ClaimCode: claimCode})
+
+	assert.NoError(t, err)
+	assert.Equal(t, claimed.ID, wallet.ID)
+	mockRepo.AssertExpectations(t)
+}
+
 // TestService_QRPayload_InvalidFormat tests QR code with invalid format
 func TestService_QRPayload_InvalidFormat(t *testing.T) {
 	service := NewService(nil, testSecretKey)
@@ -431,7 +605,7 @@ func TestService_FreezeWallet(t *testing.T) {
 
 	wallet := &Wallet{
 		ID:        walletID,
-		UserID:    uuid.New(),
+		UserID:    uuidPtr(uuid.New()),
 		Balance:   5000,
 		Status:    WalletStatusActive,
 		CreatedAt: time.Now(),
@@ -445,7 +619,7 @@ func TestService_FreezeWallet(t *testing.T) {
 
 	service := NewService(mockRepo, testSecretKey)
 
-	frozenWallet, err := service.FreezeWallet(context.Background(), walletID)
+	frozenWallet, err := service.FreezeWallet(context.Background(), walletID, FreezeWalletRequest{Reason: FreezeReasonLostWristband}, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, frozenWallet)
@@ -461,7 +635,7 @@ func TestService_UnfreezeWallet(t *testing.T) {
 
 	wallet := &Wallet{
 		ID:        walletID,
-		UserID:    uuid.New(),
+		UserID:    uuidPtr(uuid.New()),
 		Balance:   5000,
 		Status:    WalletStatusFrozen,
 		CreatedAt: time.Now(),
@@ -632,3 +806,436 @@ func TestService_RefundTransaction(t *testing.T) {
 		})
 	}
 }
+
+// TestService_ReplacePayment tests refunding a purchase and charging its correction
+func TestService_ReplacePayment(t *testing.T) {
+	walletID := uuid.New()
+	standID := uuid.New()
+	staffID := uuid.New()
+
+	t.Run("refunds the original and charges the new amount", func(t *testing.T) {
+		mockRepo := NewMockRepository()
+		txID := uuid.New()
+		originalTx := &Transaction{
+			ID:       txID,
+			WalletID: walletID,
+			Type:     TransactionTypePurchase,
+			Amount:   -500,
+			Status:   TransactionStatusCompleted,
+		}
+		mockRepo.On("GetTransactionByID", mock.Anything, txID).Return(originalTx, nil)
+		mockRepo.On("ReplacePaymentAtomic", mock.Anything, walletID,
+			mock.MatchedBy(func(tx *Transaction) bool { return tx.Type == TransactionTypeRefund && tx.Amount == 500 }),
+			mock.MatchedBy(func(tx *Transaction) bool { return tx.Type == TransactionTypePurchase && tx.Reference == txID.String() }),
+			int64(300), txID).Return(nil)
+
+		service := NewService(mockRepo, testSecretKey)
+
+		refundTx, purchaseTx, err := service.ReplacePayment(context.Background(), txID, PaymentRequest{
+			WalletID: walletID,
+			Amount:   300,
+			StandID:  standID,
+		}, "WRONG_ITEM", staffID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, TransactionTypeRefund, refundTx.Type)
+		assert.Equal(t, TransactionTypePurchase, purchaseTx.Type)
+		assert.Equal(t, &staffID, purchaseTx.StaffID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects an already refunded purchase", func(t *testing.T) {
+		mockRepo := NewMockRepository()
+		txID := uuid.New()
+		mockRepo.On("GetTransactionByID", mock.Anything, txID).Return(&Transaction{
+			ID:       txID,
+			WalletID: walletID,
+			Type:     TransactionTypePurchase,
+			Status:   TransactionStatusRefunded,
+		}, nil)
+
+		service := NewService(mockRepo, testSecretKey)
+
+		_, _, err := service.ReplacePayment(context.Background(), txID, PaymentRequest{
+			WalletID: walletID,
+			Amount:   300,
+			StandID:  standID,
+		}, "WRONG_ITEM", staffID)
+
+		assert.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestService_InitiateMerge tests opening a wallet merge
+func TestService_InitiateMerge(t *testing.T) {
+	festivalID := uuid.New()
+	newWallet := func(balance int64) *Wallet {
+		return &Wallet{
+			ID:         uuid.New(),
+			UserID:     uuidPtr(uuid.New()),
+			FestivalID: festivalID,
+			Balance:    balance,
+			Status:     WalletStatusActive,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func(*MockRepository, *Wallet, *Wallet)
+		wantErr   error
+		wantNew   bool
+	}{
+		{
+			name: "opens pending merge",
+			setupMock: func(m *MockRepository, source, target *Wallet) {
+				m.On("GetWalletByID", mock.Anything, source.ID).Return(source, nil)
+				m.On("GetWalletByID", mock.Anything, target.ID).Return(target, nil)
+				m.On("GetOpenMergeBySource", mock.Anything, source.ID).Return(nil, nil)
+				m.On("CreateMerge", mock.Anything, mock.MatchedBy(func(merge *WalletMerge) bool {
+					return merge.Status == MergeStatusPending &&
+						*merge.SourceUserID == *source.UserID &&
+						merge.TargetUserID == *target.UserID &&
+						merge.Amount == source.Balance
+				})).Return(nil)
+			},
+			wantNew: true,
+		},
+		{
+			name: "returns existing merge into same target",
+			setupMock: func(m *MockRepository, source, target *Wallet) {
+				m.On("GetWalletByID", mock.Anything, source.ID).Return(source, nil)
+				m.On("GetWalletByID", mock.Anything, target.ID).Return(target, nil)
+				m.On("GetOpenMergeBySource", mock.Anything, source.ID).Return(&WalletMerge{
+					ID:             uuid.New(),
+					SourceWalletID: source.ID,
+					TargetWalletID: target.ID,
+					Status:         MergeStatusCompleted,
+				}, nil)
+			},
+		},
+		{
+			name: "rejects pending merge into another target",
+			setupMock: func(m *MockRepository, source, target *Wallet) {
+				m.On("GetWalletByID", mock.Anything, source.ID).Return(source, nil)
+				m.On("GetWalletByID", mock.Anything, target.ID).Return(target, nil)
+				m.On("GetOpenMergeBySource", mock.Anything, source.ID).Return(&WalletMerge{
+					ID:             uuid.New(),
+					SourceWalletID: source.ID,
+					TargetWalletID: uuid.New(),
+					Status:         MergeStatusPending,
+				}, nil)
+			},
+			wantErr: ErrMergeAlreadyPending,
+		},
+		{
+			name: "rejects wallets of different festivals",
+			setupMock: func(m *MockRepository, source, target *Wallet) {
+				target.FestivalID = uuid.New()
+				m.On("GetWalletByID", mock.Anything, source.ID).Return(source, nil)
+				m.On("GetWalletByID", mock.Anything, target.ID).Return(target, nil)
+			},
+			wantErr: ErrMergeFestivalMismatch,
+		},
+		{
+			name: "rejects frozen wallet",
+			setupMock: func(m *MockRepository, source, target *Wallet) {
+				source.Status = WalletStatusFrozen
+				m.On("GetWalletByID", mock.Anything, source.ID).Return(source, nil)
+				m.On("GetWalletByID", mock.Anything, target.ID).Return(target, nil)
+				m.On("GetOpenMergeBySource", mock.Anything, source.ID).Return(nil, nil)
+			},
+			wantErr: ErrMergeWalletNotActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRepository()
+			source, target := newWallet(2500), newWallet(1000)
+			tt.setupMock(mockRepo, source, target)
+
+			service := NewService(mockRepo, testSecretKey)
+
+			merge, err := service.InitiateMerge(context.Background(), InitiateMergeRequest{
+				SourceWalletID: source.ID,
+				TargetWalletID: target.ID,
+			}, nil)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, merge)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, merge)
+				assert.Equal(t, target.ID, merge.TargetWalletID)
+				if tt.wantNew {
+					assert.Equal(t, MergeStatusPending, merge.Status)
+				}
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestService_InitiateMerge_SameWallet tests that a wallet cannot be merged into itself
+func TestService_InitiateMerge_SameWallet(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, testSecretKey)
+
+	walletID := uuid.New()
+	merge, err := service.InitiateMerge(context.Background(), InitiateMergeRequest{
+		SourceWalletID: walletID,
+		TargetWalletID: walletID,
+	}, nil)
+
+	assert.ErrorIs(t, err, ErrMergeSameWallet)
+	assert.Nil(t, merge)
+}
+
+// TestService_ConfirmMerge tests confirming a wallet merge
+func TestService_ConfirmMerge(t *testing.T) {
+	targetUserID := uuid.New()
+	otherUserID := uuid.New()
+
+	tests := []struct {
+		name      string
+		status    MergeStatus
+		userID    *uuid.UUID
+		setupMock func(*MockRepository, *WalletMerge)
+		wantErr   bool
+	}{
+		{
+			name:   "target user confirms pending merge",
+			status: MergeStatusPending,
+			userID: &targetUserID,
+			setupMock: func(m *MockRepository, merge *WalletMerge) {
+				m.On("MergeWalletsAtomic", mock.Anything, merge, &targetUserID).Return(nil)
+			},
+		},
+		{
+			name:      "completed merge is returned unchanged",
+			status:    MergeStatusCompleted,
+			userID:    &targetUserID,
+			setupMock: func(m *MockRepository, merge *WalletMerge) {},
+		},
+		{
+			name:      "cancelled merge cannot be confirmed",
+			status:    MergeStatusCancelled,
+			setupMock: func(m *MockRepository, merge *WalletMerge) {},
+			wantErr:   true,
+		},
+		{
+			name:      "source user cannot confirm",
+			status:    MergeStatusPending,
+			userID:    &otherUserID,
+			setupMock: func(m *MockRepository, merge *WalletMerge) {},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRepository()
+			merge := &WalletMerge{
+				ID:             uuid.New(),
+				SourceWalletID: uuid.New(),
+				TargetWalletID: uuid.New(),
+				SourceUserID:   &otherUserID,
+				TargetUserID:   targetUserID,
+				Status:         tt.status,
+			}
+			mockRepo.On("GetMergeByID", mock.Anything, merge.ID).Return(merge, nil)
+			tt.setupMock(mockRepo, merge)
+
+			service := NewService(mockRepo, testSecretKey)
+
+			confirmed, err := service.ConfirmMerge(context.Background(), merge.ID, tt.userID, tt.userID)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, confirmed)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, merge.ID, confirmed.ID)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestService_CancelMerge tests cancelling a wallet merge
+func TestService_CancelMerge(t *testing.T) {
+	mockRepo := NewMockRepository()
+	staffID := uuid.New()
+
+	merge := &WalletMerge{
+		ID:     uuid.New(),
+		Status: MergeStatusPending,
+	}
+
+	mockRepo.On("GetMergeByID", mock.Anything, merge.ID).Return(merge, nil)
+	mockRepo.On("CancelMerge", mock.Anything, mock.MatchedBy(func(m *WalletMerge) bool {
+		return m.CancelledBy != nil && *m.CancelledBy == staffID && m.CancelledAt != nil
+	})).Return(nil)
+
+	service := NewService(mockRepo, testSecretKey)
+
+	cancelled, err := service.CancelMerge(context.Background(), merge.ID, &staffID, nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, cancelled)
+
+	// A completed merge cannot be cancelled
+	completed := &WalletMerge{ID: uuid.New(), Status: MergeStatusCompleted}
+	mockRepo.On("GetMergeByID", mock.Anything, completed.ID).Return(completed, nil)
+
+	_, err = service.CancelMerge(context.Background(), completed.ID, &staffID, nil)
+	assert.ErrorIs(t, err, ErrMergeNotPending)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestService_CreateAnonymousWallet tests selling a wallet without a user account
+func TestService_CreateAnonymousWallet(t *testing.T) {
+	mockRepo := NewMockRepository()
+	festivalID := uuid.New()
+	staffID := uuid.New()
+
+	var storedHash string
+	mockRepo.On("CreateWalletWithTransaction", mock.Anything,
+		mock.MatchedBy(func(w *Wallet) bool {
+			if w.UserID != nil || w.ClaimCodeHash == nil {
+				return false
+			}
+			storedHash = *w.ClaimCodeHash
+			return w.FestivalID == festivalID
+		}),
+		mock.MatchedBy(func(tx *Transaction) bool {
+			return tx.Type == TransactionTypeCashIn && tx.Amount == 2000
+		}),
+	).Return(nil)
+
+	service := NewService(mockRepo, testSecretKey)
+
+	wallet, tx, claimCode, err := service.CreateAnonymousWallet(context.Background(), CreateAnonymousWalletRequest{
+		FestivalID: festivalID,
+		Amount:     2000,
+	}, &staffID)
+
+	assert.NoError(t, err)
+	assert.True(t, wallet.IsAnonymous())
+	assert.Equal(t, wallet.ID, tx.WalletID)
+	assert.Regexp(t, `^[A-HJ-NP-Z2-9]{4}-[A-HJ-NP-Z2-9]{4}-[A-HJ-NP-Z2-9]{4}$`, claimCode)
+
+	// The stored hash matches the code however the attendee types it
+	assert.Equal(t, storedHash, service.hashClaimCode(claimCode))
+	assert.Equal(t, storedHash, service.hashClaimCode(strings.ToLower(strings.ReplaceAll(claimCode, "-", " "))))
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestService_ClaimWallet tests claiming an anonymous wallet
+func TestService_ClaimWallet(t *testing.T) {
+	const claimCode = "ABCD-EFGH-JKLM"
+	userID := uuid.New()
+	festivalID := uuid.New()
+
+	tests := []struct {
+		name      string
+		wallet    *Wallet
+		setupMock func(*MockRepository, *Wallet)
+		wantErr   error
+	}{
+		{
+			name:   "attaches anonymous wallet to user",
+			wallet: &Wallet{ID: uuid.New(), FestivalID: festivalID, Balance: 2000, Status: WalletStatusActive},
+			setupMock: func(m *MockRepository, w *Wallet) {
+				m.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(nil, nil)
+				m.On("ClaimWallet", mock.Anything, w.ID, userID, mock.AnythingOfType("time.Time")).Return(nil)
+			},
+		},
+		{
+			name:      "claiming own wallet again returns it",
+			wallet:    &Wallet{ID: uuid.New(), UserID: &userID, FestivalID: festivalID, Status: WalletStatusActive},
+			setupMock: func(m *MockRepository, w *Wallet) {},
+		},
+		{
+			name:      "wallet claimed by another user",
+			wallet:    &Wallet{ID: uuid.New(), UserID: uuidPtr(uuid.New()), FestivalID: festivalID, Status: WalletStatusActive},
+			setupMock: func(m *MockRepository, w *Wallet) {},
+			wantErr:   ErrWalletAlreadyClaimed,
+		},
+		{
+			name:      "unknown claim code",
+			setupMock: func(m *MockRepository, w *Wallet) {},
+			wantErr:   ErrInvalidClaimCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockRepository()
+			service := NewService(mockRepo, testSecretKey)
+
+			if tt.wallet != nil {
+				mockRepo.On("GetWalletByClaimCode", mock.Anything, service.hashClaimCode(claimCode)).Return(tt.wallet, nil)
+			} else {
+				mockRepo.On("GetWalletByClaimCode", mock.Anything, service.hashClaimCode(claimCode)).Return(nil, nil)
+			}
+			tt.setupMock(mockRepo, tt.wallet)
+
+			wallet, err := service.ClaimWallet(context.Background(), userID, ClaimWalletRequest{ClaimCode: claimCode})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, wallet)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, wallet.IsOwnedBy(userID))
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestService_ClaimWallet_MergesIntoExistingWallet tests that claiming a wallet
+// merges it into the wallet the user already has for the festival
+func TestService_ClaimWallet_MergesIntoExistingWallet(t *testing.T) {
+	const claimCode = "ABCD-EFGH-JKLM"
+	mockRepo := NewMockRepository()
+	service := NewService(mockRepo, testSecretKey)
+
+	userID := uuid.New()
+	festivalID := uuid.New()
+	anonymous := &Wallet{ID: uuid.New(), FestivalID: festivalID, Balance: 2000, Status: WalletStatusActive}
+	existing := &Wallet{ID: uuid.New(), UserID: &userID, FestivalID: festivalID, Balance: 500, Status: WalletStatusActive}
+
+	mockRepo.On("GetWalletByClaimCode", mock.Anything, service.hashClaimCode(claimCode)).Return(anonymous, nil)
+	mockRepo.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(existing, nil)
+	mockRepo.On("GetWalletByID", mock.Anything, anonymous.ID).Return(anonymous, nil)
+	mockRepo.On("GetWalletByID", mock.Anything, existing.ID).Return(existing, nil)
+	mockRepo.On("GetOpenMergeBySource", mock.Anything, anonymous.ID).Return(nil, nil)
+
+	mockRepo.On("CreateMerge", mock.Anything, mock.MatchedBy(func(m *WalletMerge) bool {
+		return m.SourceUserID == nil && m.TargetUserID == userID
+	})).Return(nil)
+	mockRepo.On("GetMergeByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&WalletMerge{
+		ID:             uuid.New(),
+		SourceWalletID: anonymous.ID,
+		TargetWalletID: existing.ID,
+		TargetUserID:   userID,
+		Status:         MergeStatusPending,
+	}, nil)
+	mockRepo.On("MergeWalletsAtomic", mock.Anything, mock.AnythingOfType("*wallet.WalletMerge"), &userID).Return(nil)
+
+	wallet, err := service.ClaimWallet(context.Background(), userID, ClaimWalletRequest{ClaimCode: claimCode})
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, wallet.ID)
+
+	mockRepo.AssertExpectations(t)
+}
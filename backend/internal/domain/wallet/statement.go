@@ -0,0 +1,197 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+)
+
+// statementMaxTransactions bounds the history loaded for a statement
+const statementMaxTransactions = 10000
+
+// StatementRenderer renders wallet statements as PDF; satisfied by reports.Service
+type StatementRenderer interface {
+	RenderWalletStatement(statement *reports.WalletStatement, locale string) ([]byte, error)
+}
+
+// StatementBrandingProvider returns the festival identity printed on statements;
+// satisfied by branding.Service
+type StatementBrandingProvider interface {
+	GetReportBranding(ctx context.Context, festivalID uuid.UUID) (*reports.Branding, error)
+}
+
+// StatementMailer sends a rendered statement to the wallet holder; satisfied by jobs.EmailQueue
+type StatementMailer interface {
+	SendWalletStatement(ctx context.Context, email StatementEmail) error
+}
+
+// StatementEmail is a rendered statement to send by email
+type StatementEmail struct {
+	To           string
+	Locale       string
+	FestivalID   uuid.UUID
+	FestivalName string
+	From         time.Time
+	Until        time.Time
+	Filename     string
+	PDF          []byte
+}
+
+// StatementRequest selects the period of a statement and how it is delivered
+type StatementRequest struct {
+	From        *time.Time // Start of the wallet history when nil
+	To          *time.Time // Now when nil
+	HolderEmail string
+	Locale      string
+	Email       bool // Send the statement to HolderEmail instead of returning it
+}
+
+// Statement is a rendered wallet statement
+type Statement struct {
+	Filename string
+	PDF      []byte
+}
+
+// SetStatementRenderer sets the renderer of wallet statements
+func (s *Service) SetStatementRenderer(renderer StatementRenderer) {
+	s.statementRenderer = renderer
+}
+
+// SetStatementBranding sets the provider of the festival identity printed on statements
+func (s *Service) SetStatementBranding(provider StatementBrandingProvider) {
+	s.statementBranding = provider
+}
+
+// SetStatementMailer sets the mailer sending statements to wallet holders
+func (s *Service) SetStatementMailer(mailer StatementMailer) {
+	s.statementMailer = mailer
+}
+
+// GetStatement renders the statement of a user's wallet in a festival, and emails it to
+// the holder when requested
+func (s *Service) GetStatement(ctx context.Context, userID, festivalID uuid.UUID, req StatementRequest) (*Statement, error) {
+	if s.statementRenderer == nil {
+		return nil, ErrStatementUnavailable
+	}
+	if req.Email && s.statementMailer == nil {
+		return nil, ErrStatementUnavailable
+	}
+
+	wallet, err := s.repo.GetWalletByUserAndFestival(ctx, userID, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := wallet.CreatedAt
+	if req.From != nil {
+		from = *req.From
+	}
+	if from.After(to) {
+		return nil, ErrStatementPeriod
+	}
+
+	transactions, _, err := s.repo.GetTransactionsByWallet(ctx, wallet.ID, 0, statementMaxTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	statement := BuildStatement(wallet, transactions, from, to)
+	statement.HolderEmail = req.HolderEmail
+	if s.statementBranding != nil {
+		branding, err := s.statementBranding.GetReportBranding(ctx, festivalID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get festival branding: %w", err)
+		}
+		statement.Branding = *branding
+	}
+
+	pdf, err := s.statementRenderer.RenderWalletStatement(statement, req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	result := &Statement{
+		Filename: fmt.Sprintf("statement_%s_%s.pdf", wallet.ID.String()[:8], to.Format("20060102")),
+		PDF:      pdf,
+	}
+
+	if req.Email {
+		if err := s.statementMailer.SendWalletStatement(ctx, StatementEmail{
+			To:           req.HolderEmail,
+			Locale:       req.Locale,
+			FestivalID:   festivalID,
+			FestivalName: statement.Branding.FestivalName,
+			From:         from,
+			Until:        to,
+			Filename:     result.Filename,
+			PDF:          pdf,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to send statement: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// BuildStatement computes the statement of a wallet over [from, to] from its transactions.
+// Only the wallet's own settled transactions are listed: the history of merged wallets
+// is reflected by the MERGE transaction that credited their balance.
+func BuildStatement(wallet *Wallet, transactions []Transaction, from, to time.Time) *reports.WalletStatement {
+	own := make([]Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		if tx.WalletID != wallet.ID {
+			continue
+		}
+		if tx.Status != TransactionStatusCompleted && tx.Status != TransactionStatusRefunded {
+			continue
+		}
+		own = append(own, tx)
+	}
+	sort.SliceStable(own, func(i, j int) bool { return own[i].CreatedAt.Before(own[j].CreatedAt) })
+
+	statement := &reports.WalletStatement{
+		WalletID: wallet.ID,
+		From:     from,
+		To:       to,
+		Lines:    []reports.StatementLine{},
+	}
+	for _, tx := range own {
+		if tx.CreatedAt.Before(from) {
+			statement.OpeningBalance = tx.BalanceAfter
+			continue
+		}
+		if tx.CreatedAt.After(to) {
+			break
+		}
+		statement.Lines = append(statement.Lines, reports.StatementLine{
+			Date:        tx.CreatedAt,
+			Type:        string(tx.Type),
+			Description: tx.Metadata.Description,
+			Reference:   tx.Reference,
+			Amount:      tx.Amount,
+			Balance:     tx.BalanceAfter,
+		})
+		if tx.Amount >= 0 {
+			statement.TotalCredited += tx.Amount
+		} else {
+			statement.TotalDebited -= tx.Amount
+		}
+	}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	if len(statement.Lines) > 0 {
+		statement.ClosingBalance = statement.Lines[len(statement.Lines)-1].Balance
+	}
+	return statement
+}
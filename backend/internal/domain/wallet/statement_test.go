@@ -0,0 +1,127 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func statementTransaction(walletID uuid.UUID, txType TransactionType, amount, balanceAfter int64, at time.Time) Transaction {
+	return Transaction{
+		ID:            uuid.New(),
+		WalletID:      walletID,
+		Type:          txType,
+		Amount:        amount,
+		BalanceBefore: balanceAfter - amount,
+		BalanceAfter:  balanceAfter,
+		Status:        TransactionStatusCompleted,
+		CreatedAt:     at,
+	}
+}
+
+func TestBuildStatement(t *testing.T) {
+	wallet := &Wallet{ID: uuid.New()}
+	day := time.Date(2026, 7, 12, 0, 0, 0, 0, time.UTC)
+
+	failed := statementTransaction(wallet.ID, TransactionTypePurchase, -700, 1300, day.Add(15*time.Hour))
+	failed.Status = TransactionStatusFailed
+	merged := statementTransaction(uuid.New(), TransactionTypePurchase, -100, 900, day.Add(16*time.Hour))
+
+	// Newest first, as returned by the repository
+	transactions := []Transaction{
+		statementTransaction(wallet.ID, TransactionTypeTopUp, 1000, 2500, day.Add(48*time.Hour)),
+		merged,
+		statementTransaction(wallet.ID, TransactionTypePurchase, -450, 1500, day.Add(20*time.Hour)),
+		failed,
+		statementTransaction(wallet.ID, TransactionTypeMerge, 950, 1950, day.Add(14*time.Hour)),
+		statementTransaction(wallet.ID, TransactionTypeTopUp, 2000, 2000, day.Add(-24*time.Hour)),
+		statementTransaction(wallet.ID, TransactionTypePurchase, -1000, 1000, day.Add(-12*time.Hour)),
+	}
+	// Out of order on purpose: the opening balance is the one after the last transaction
+	transactions[5], transactions[6] = transactions[6], transactions[5]
+
+	statement := BuildStatement(wallet, transactions, day, day.Add(24*time.Hour-time.Nanosecond))
+
+	assert.Equal(t, int64(1000), statement.OpeningBalance)
+	require.Len(t, statement.Lines, 2)
+	assert.Equal(t, string(TransactionTypeMerge), statement.Lines[0].Type)
+	assert.Equal(t, string(TransactionTypePurchase), statement.Lines[1].Type)
+	assert.Equal(t, int64(950), statement.TotalCredited)
+	assert.Equal(t, int64(450), statement.TotalDebited)
+	assert.Equal(t, int64(1500), statement.ClosingBalance)
+	assert.Equal(t, statement.OpeningBalance+statement.TotalCredited-statement.TotalDebited, statement.ClosingBalance)
+}
+
+func TestBuildStatement_NoTransactionsInPeriod(t *testing.T) {
+	wallet := &Wallet{ID: uuid.New()}
+	day := time.Date(2026, 7, 12, 0, 0, 0, 0, time.UTC)
+	transactions := []Transaction{
+		statementTransaction(wallet.ID, TransactionTypeTopUp, 3000, 3000, day.Add(-time.Hour)),
+	}
+
+	statement := BuildStatement(wallet, transactions, day, day.Add(24*time.Hour))
+
+	assert.Empty(t, statement.Lines)
+	assert.Equal(t, int64(3000), statement.OpeningBalance)
+	assert.Equal(t, int64(3000), statement.ClosingBalance)
+}
+
+type fakeStatementMailer struct {
+	sent []StatementEmail
+}
+
+func (m *fakeStatementMailer) SendWalletStatement(ctx context.Context, email StatementEmail) error {
+	m.sent = append(m.sent, email)
+	return nil
+}
+
+func TestService_GetStatement(t *testing.T) {
+	userID := uuid.New()
+	festivalID := uuid.New()
+	wallet := &Wallet{ID: uuid.New(), UserID: &userID, FestivalID: festivalID, CreatedAt: time.Now().Add(-48 * time.Hour)}
+
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(wallet, nil)
+	mockRepo.On("GetTransactionsByWallet", mock.Anything, wallet.ID, 0, statementMaxTransactions).Return([]Transaction{
+		statementTransaction(wallet.ID, TransactionTypeTopUp, 2000, 2000, time.Now().Add(-time.Hour)),
+	}, int64(1), nil)
+
+	mailer := &fakeStatementMailer{}
+	service := NewService(mockRepo, testSecretKey)
+	service.SetStatementRenderer(reports.NewService(nil, nil, nil, ""))
+	service.SetStatementMailer(mailer)
+
+	statement, err := service.GetStatement(context.Background(), userID, festivalID, StatementRequest{
+		HolderEmail: "attendee@example.com",
+		Locale:      "fr",
+		Email:       true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF", string(statement.PDF[:4]))
+
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, "attendee@example.com", mailer.sent[0].To)
+	assert.Equal(t, statement.Filename, mailer.sent[0].Filename)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetStatement_InvalidPeriod(t *testing.T) {
+	userID := uuid.New()
+	festivalID := uuid.New()
+	mockRepo := NewMockRepository()
+	mockRepo.On("GetWalletByUserAndFestival", mock.Anything, userID, festivalID).Return(&Wallet{ID: uuid.New()}, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+	service.SetStatementRenderer(reports.NewService(nil, nil, nil, ""))
+
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	_, err := service.GetStatement(context.Background(), userID, festivalID, StatementRequest{From: &from, To: &to})
+	assert.ErrorIs(t, err, ErrStatementPeriod)
+}
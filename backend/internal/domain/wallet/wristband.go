@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+)
+
+// CredentialBroadcaster pushes credential revocations to the POS devices of a festival
+// as they happen; satisfied by realtime.Service
+type CredentialBroadcaster interface {
+	BroadcastCredentialRevocation(ctx context.Context, festivalID string, revocation interface{})
+}
+
+// AuditLogger records the wristband replacements, satisfied by audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// CredentialRevocation tells POS devices to refuse the wristbands of a wallet and its
+// QR codes below a generation from now on, without waiting for the next offline spec
+type CredentialRevocation struct {
+	WalletID      uuid.UUID         `json:"walletId"`
+	WristbandUIDs []string          `json:"wristbandUids"`
+	MinGeneration int               `json:"minGeneration"`
+	Reason        ReplacementReason `json:"reason"`
+	RevokedAt     time.Time         `json:"revokedAt"`
+}
+
+// SetCredentialBroadcaster sets the broadcaster pushing revoked wristbands to POS devices
+func (s *Service) SetCredentialBroadcaster(broadcaster CredentialBroadcaster) {
+	s.credentialBroadcaster = broadcaster
+}
+
+// SetAuditLogger records the wristband replacements in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.auditLogger = logger
+}
+
+// ReplaceWristband replaces the wristband of a wallet, e.g. after a loss, keeping its
+// balance and history. The old wristbands are revoked and pushed to the POS devices of
+// the festival, the QR material rotated, and the new wristband linked to the wallet. An
+// anonymous wallet gets a new claim code, returned once, since the old one was printed
+// on the card handed out with the lost wristband. A freeze for the lost or stolen
+// wristband is lifted.
+func (s *Service) ReplaceWristband(ctx context.Context, walletID uuid.UUID, req ReplaceWristbandRequest, staffID *uuid.UUID) (*WristbandReplacement, *Wallet, string, error) {
+	newUID := strings.TrimSpace(req.NewUID)
+	oldUID := strings.TrimSpace(req.OldUID)
+	if newUID == oldUID {
+		return nil, nil, "", ErrWristbandSameUID
+	}
+
+	wallet, err := s.repo.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if wallet == nil {
+		return nil, nil, "", errors.ErrNotFound
+	}
+	if wallet.Status == WalletStatusClosed {
+		return nil, nil, "", ErrReplaceClosedWallet
+	}
+
+	var claimCode string
+	var claimCodeHash *string
+	if wallet.IsAnonymous() {
+		claimCode, err = generateClaimCode()
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to generate claim code: %w", err)
+		}
+		hash := s.hashClaimCode(claimCode)
+		claimCodeHash = &hash
+	}
+
+	replacement := &WristbandReplacement{
+		ID:         uuid.New(),
+		WalletID:   wallet.ID,
+		NewUID:     newUID,
+		Reason:     req.Reason,
+		Note:       req.Note,
+		ReplacedBy: staffID,
+	}
+	wallet, err = s.repo.ReplaceWristbandAtomic(ctx, replacement, oldUID, claimCodeHash)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	s.walletChanged(ctx, wallet.ID)
+
+	if s.credentialBroadcaster != nil {
+		s.credentialBroadcaster.BroadcastCredentialRevocation(ctx, wallet.FestivalID.String(), CredentialRevocation{
+			WalletID:      wallet.ID,
+			WristbandUIDs: replacement.OldUIDs,
+			MinGeneration: replacement.QRGeneration,
+			Reason:        replacement.Reason,
+			RevokedAt:     replacement.CreatedAt,
+		})
+	}
+
+	if s.auditLogger != nil {
+		s.auditLogger.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+			UserID:     staffID,
+			Action:     audit.ActionWalletCredentialReplace,
+			Resource:   "wallet",
+			ResourceID: wallet.ID.String(),
+			FestivalID: &wallet.FestivalID,
+			Metadata: map[string]interface{}{
+				"replacementId":     replacement.ID.String(),
+				"oldUids":           []string(replacement.OldUIDs),
+				"newUid":            replacement.NewUID,
+				"reason":            replacement.Reason,
+				"note":              replacement.Note,
+				"qrGeneration":      replacement.QRGeneration,
+				"claimCodeReissued": replacement.ClaimCodeReissued,
+				"unfrozen":          replacement.Unfrozen,
+				"balance":           wallet.Balance,
+			},
+		})
+	}
+
+	return replacement, wallet, claimCode, nil
+}
+
+// GetWristbandReplacements gets the wristband replacements of a wallet, latest first
+func (s *Service) GetWristbandReplacements(ctx context.Context, walletID uuid.UUID) ([]WristbandReplacement, error) {
+	if _, err := s.GetWallet(ctx, walletID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWristbandReplacements(ctx, walletID)
+}
@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredentialBroadcaster struct {
+	festivalID  string
+	revocations []CredentialRevocation
+}
+
+func (b *fakeCredentialBroadcaster) BroadcastCredentialRevocation(ctx context.Context, festivalID string, revocation interface{}) {
+	b.festivalID = festivalID
+	b.revocations = append(b.revocations, revocation.(CredentialRevocation))
+}
+
+type fakeAuditLogger struct {
+	logs []audit.CreateAuditLogRequest
+}
+
+func (l *fakeAuditLogger) LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest) {
+	l.logs = append(l.logs, req)
+}
+
+// completeReplacement stands for the repository completing a replacement
+func completeReplacement(wallet Wallet, revoked ...string) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		replacement := args.Get(1).(*WristbandReplacement)
+		replacement.FestivalID = wallet.FestivalID
+		replacement.OldUIDs = revoked
+		replacement.QRGeneration = wallet.QRGeneration
+		replacement.ClaimCodeReissued = args.Get(3).(*string) != nil
+		replacement.CreatedAt = time.Now()
+	}
+}
+
+func TestService_ReplaceWristband(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	festivalID := uuid.New()
+	staffID := uuid.New()
+
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(&Wallet{
+		ID:         walletID,
+		UserID:     uuidPtr(uuid.New()),
+		FestivalID: festivalID,
+		Balance:    4250,
+		Status:     WalletStatusActive,
+	}, nil)
+	replaced := Wallet{ID: walletID, FestivalID: festivalID, Balance: 4250, Status: WalletStatusActive, QRGeneration: 1}
+	mockRepo.On("ReplaceWristbandAtomic", mock.Anything, mock.AnythingOfType("*wallet.WristbandReplacement"), "04A1B2C3", (*string)(nil)).
+		Run(completeReplacement(replaced, "04A1B2C3")).
+		Return(&replaced, nil)
+
+	broadcaster := &fakeCredentialBroadcaster{}
+	auditLogger := &fakeAuditLogger{}
+	service := NewService(mockRepo, testSecretKey)
+	service.SetCredentialBroadcaster(broadcaster)
+	service.SetAuditLogger(auditLogger)
+
+	replacement, wallet, claimCode, err := service.ReplaceWristband(context.Background(), walletID, ReplaceWristbandRequest{
+		NewUID: " 04FFEEDD ",
+		OldUID: "04A1B2C3",
+		Reason: ReplacementReasonLost,
+	}, &staffID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "04FFEEDD", replacement.NewUID)
+	assert.Equal(t, &staffID, replacement.ReplacedBy)
+	assert.Equal(t, int64(4250), wallet.Balance, "balance preserved")
+	assert.Empty(t, claimCode, "claimed wallets keep their account")
+
+	require.Len(t, broadcaster.revocations, 1)
+	assert.Equal(t, festivalID.String(), broadcaster.festivalID)
+	assert.Equal(t, []string{"04A1B2C3"}, broadcaster.revocations[0].WristbandUIDs)
+	assert.Equal(t, 1, broadcaster.revocations[0].MinGeneration)
+
+	require.Len(t, auditLogger.logs, 1)
+	assert.Equal(t, audit.ActionWalletCredentialReplace, auditLogger.logs[0].Action)
+	assert.Equal(t, &staffID, auditLogger.logs[0].UserID)
+	assert.Equal(t, walletID.String(), auditLogger.logs[0].ResourceID)
+	assert.Equal(t, []string{"04A1B2C3"}, auditLogger.logs[0].Metadata["oldUids"])
+	assert.Equal(t, "04FFEEDD", auditLogger.logs[0].Metadata["newUid"])
+}
+
+func TestService_ReplaceWristband_AnonymousWallet(t *testing.T) {
+	mockRepo := NewMockRepository()
+	walletID := uuid.New()
+	oldHash := "old-claim-code-hash"
+
+	anonymous := Wallet{ID: walletID, FestivalID: uuid.New(), Status: WalletStatusFrozen, ClaimCodeHash: &oldHash}
+	mockRepo.On("GetWalletByID", mock.Anything, walletID).Return(&anonymous, nil)
+	mockRepo.On("ReplaceWristbandAtomic", mock.Anything, mock.AnythingOfType("*wallet.WristbandReplacement"), "", mock.AnythingOfType("*string")).
+		Run(completeReplacement(anonymous)).
+		Return(&anonymous, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+	replacement, _, claimCode, err := service.ReplaceWristband(context.Background(), walletID, ReplaceWristbandRequest{
+		NewUID: "04FFEEDD",
+		Reason: ReplacementReasonStolen,
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Len(t, claimCode, 14, "new claim code returned once")
+	assert.True(t, replacement.ClaimCodeReissued)
+	hash := mockRepo.Calls[1].Arguments.Get(3).(*string)
+	assert.Equal(t, service.hashClaimCode(claimCode), *hash)
+}
+
+func TestService_ReplaceWristband_Refused(t *testing.T) {
+	mockRepo := NewMockRepository()
+	closedID := uuid.New()
+	mockRepo.On("GetWalletByID", mock.Anything, closedID).Return(&Wallet{ID: closedID, Status: WalletStatusClosed}, nil)
+	missingID := uuid.New()
+	mockRepo.On("GetWalletByID", mock.Anything, missingID).Return(nil, nil)
+
+	service := NewService(mockRepo, testSecretKey)
+	ctx := context.Background()
+
+	_, _, _, err := service.ReplaceWristband(ctx, closedID, ReplaceWristbandRequest{NewUID: "04FFEEDD", Reason: ReplacementReasonLost}, nil)
+	assert.ErrorIs(t, err, ErrReplaceClosedWallet)
+
+	_, _, _, err = service.ReplaceWristband(ctx, missingID, ReplaceWristbandRequest{NewUID: "04FFEEDD", Reason: ReplacementReasonLost}, nil)
+	assert.Error(t, err)
+
+	_, _, _, err = service.ReplaceWristband(ctx, closedID, ReplaceWristbandRequest{NewUID: "04FFEEDD", OldUID: "04FFEEDD", Reason: ReplacementReasonDamaged}, nil)
+	assert.ErrorIs(t, err, ErrWristbandSameUID)
+
+	mockRepo.AssertNotCalled(t, "ReplaceWristbandAtomic", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,562 @@
+package walletbatch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+// maxFileSize is the largest CSV file accepted, enough for MaxRows rows
+const maxFileSize = 5 << 20
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped wallet batches, which should be
+// restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	batches := r.Group("/wallet-batches")
+	{
+		batches.GET("", h.List)
+		batches.POST("", h.Create)
+		batches.POST("/csv", h.CreateFromCSV)
+		batches.GET("/:batchId", h.Get)
+		batches.GET("/:batchId/items", h.ListItems)
+		batches.GET("/:batchId/report", h.GetReport)
+		batches.GET("/:batchId/diff", h.GetDiff)
+		batches.GET("/:batchId/events", h.ListEvents)
+		batches.POST("/:batchId/approve", h.Approve)
+		batches.POST("/:batchId/reject", h.Reject)
+		batches.POST("/:batchId/items/reject", h.RejectItems)
+	}
+}
+
+// List lists the wallet batches of the festival
+// @Summary List wallet batches
+// @Description List the bulk credits and debits of the festival, latest first
+// @Tags wallet-batches
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Batch,meta=response.Meta} "Wallet batches"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	page, perPage := pagination(c)
+	batches, total, err := h.service.List(c.Request.Context(), festivalID, (page-1)*perPage, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.OKWithMeta(c, batches, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Create credits or debits the wallets of a segment of the festival attendees
+// @Summary Credit or debit a segment
+// @Description Credit or debit the same amount to the active wallets of the attendees holding a valid ticket, optionally of some ticket types or checked in only. With dryRun=true the totals are previewed and nothing is credited or debited; otherwise the batch is queued for the worker, or waits for the approval of another organizer when it totals at least the approval threshold.
+// @Tags wallet-batches
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param dryRun query bool false "Preview the totals only"
+// @Param request body CreateBatchRequest true "Operation, amount in cents and segment"
+// @Success 200 {object} response.Response{data=Preview} "Dry run totals"
+// @Success 202 {object} response.Response{data=Batch} "Batch queued or awaiting approval"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 422 {object} response.ErrorResponse "No wallet matches the segment"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches [post]
+func (h *Handler) Create(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CreateBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	if dryRun(c) {
+		preview, err := h.service.PreviewSegment(c.Request.Context(), festivalID, req)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		response.OK(c, preview)
+		return
+	}
+
+	batch, err := h.service.CreateFromSegment(c.Request.Context(), festivalID, req, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, batch)
+}
+
+// CreateFromCSV credits or debits the wallets listed in a CSV file
+// @Summary Credit or debit from a CSV file
+// @Description Credit or debit the wallets listed in a CSV file with a wallet_id or email column and an optional amount column in cents. Rows without a matching active wallet are skipped. With dryRun=true the totals are previewed and nothing is credited or debited; otherwise the batch is queued for the worker, or waits for the approval of another organizer when it totals at least the approval threshold.
+// @Tags wallet-batches
+// @Accept multipart/form-data
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param dryRun query bool false "Preview the totals only"
+// @Param file formData file true "CSV file"
+// @Param operation formData string true "CREDIT or DEBIT"
+// @Param amount formData int false "Amount in cents of the rows without one"
+// @Param reason formData string true "Reason shown in the wallet history"
+// @Success 200 {object} response.Response{data=Preview} "Dry run totals"
+// @Success 202 {object} response.Response{data=Batch} "Batch queued or awaiting approval"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or file"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 422 {object} response.ErrorResponse "No wallet matches the file"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/csv [post]
+func (h *Handler) CreateFromCSV(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req CSVBatchRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "MISSING_FILE", "No file provided", nil)
+		return
+	}
+	if header.Size > maxFileSize {
+		response.BadRequest(c, "PAYLOAD_TOO_LARGE", fmt.Sprintf("CSV file must be under %d MB", maxFileSize>>20), nil)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.BadRequest(c, "INVALID_FILE", "Could not read the file", nil)
+		return
+	}
+	defer file.Close()
+
+	if dryRun(c) {
+		preview, err := h.service.PreviewCSV(c.Request.Context(), festivalID, req, file)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		response.OK(c, preview)
+		return
+	}
+
+	batch, err := h.service.CreateFromCSV(c.Request.Context(), festivalID, req, header.Filename, file, userID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, batch)
+}
+
+// Get returns a wallet batch
+// @Summary Get a wallet batch
+// @Description Get a wallet batch with its progress and the rows skipped from its file
+// @Tags wallet-batches
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Success 200 {object} response.Response{data=Batch} "Wallet batch"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId} [get]
+func (h *Handler) Get(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	batch, err := h.service.Get(c.Request.Context(), festivalID, batchID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, batch)
+}
+
+// ListItems lists the wallets of a batch with their outcome
+// @Summary List wallet batch results
+// @Description List the wallets of a batch with the outcome of their credit or debit
+// @Tags wallet-batches
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(PENDING, SUCCEEDED, FAILED, REJECTED)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]Item,meta=response.Meta} "Wallet results"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/items [get]
+func (h *Handler) ListItems(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	status, ok := itemStatus(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := pagination(c)
+	items, total, err := h.service.ListItems(c.Request.Context(), festivalID, batchID, ItemFilter{
+		Status: status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, items, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// GetReport downloads the per-wallet report of a batch
+// @Summary Download wallet batch report
+// @Description Download the outcome for each wallet of a batch as CSV, followed by the rows skipped from its file. Amounts are in cents, negative for debits.
+// @Tags wallet-batches
+// @Produce text/csv
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Success 200 {file} file "Wallet batch report"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/report [get]
+func (h *Handler) GetReport(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.service.Get(c.Request.Context(), festivalID, batchID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"wallet-batch-%s.csv\"", batchID))
+	if err := h.service.WriteReport(c.Request.Context(), festivalID, batchID, c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// GetDiff previews the change a batch makes to each wallet
+// @Summary Preview wallet batch changes
+// @Description List the wallets of a batch with their balance now and after the credit or debit, for the reviewer of a batch awaiting approval. Short debits, above the balance, would fail.
+// @Tags wallet-batches
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Param status query string false "Filter by status" Enums(PENDING, SUCCEEDED, FAILED, REJECTED)
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]DiffLine,meta=response.Meta} "Wallet changes"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/diff [get]
+func (h *Handler) GetDiff(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+	status, ok := itemStatus(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := pagination(c)
+	lines, total, err := h.service.Diff(c.Request.Context(), festivalID, batchID, ItemFilter{
+		Status: status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OKWithMeta(c, lines, &response.Meta{
+		Total:   int(total),
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// ListEvents lists the history of a batch
+// @Summary List wallet batch history
+// @Description The creation, rejected wallets, approval or rejection of a batch, with who did it, oldest first
+// @Tags wallet-batches
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Success 200 {object} response.Response{data=[]Event} "Batch history"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/events [get]
+func (h *Handler) ListEvents(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.service.ListEvents(c.Request.Context(), festivalID, batchID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, events)
+}
+
+// Approve approves a batch awaiting approval
+// @Summary Approve a wallet batch
+// @Description Queue a batch awaiting approval for the worker, less the wallets rejected by the reviewer. The reviewer must be another organizer than the creator of the batch.
+// @Tags wallet-batches
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Param request body ApproveBatchRequest false "Review note"
+// @Success 200 {object} response.Response{data=Batch} "Batch queued"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Created by the reviewer"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Failure 409 {object} response.ErrorResponse "Batch not awaiting approval"
+// @Failure 422 {object} response.ErrorResponse "Every wallet rejected"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/approve [post]
+func (h *Handler) Approve(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	var req ApproveBatchRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationFailed(c, err)
+			return
+		}
+	}
+
+	batch, err := h.service.Approve(c.Request.Context(), festivalID, batchID, userID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, batch)
+}
+
+// Reject rejects a whole batch awaiting approval
+// @Summary Reject a wallet batch
+// @Description Reject a batch awaiting approval: none of its wallets is credited or debited. Its creator may withdraw it this way.
+// @Tags wallet-batches
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Param request body RejectBatchRequest true "Reason"
+// @Success 200 {object} response.Response{data=Batch} "Batch rejected"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Failure 409 {object} response.ErrorResponse "Batch not awaiting approval"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/reject [post]
+func (h *Handler) Reject(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	var req RejectBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	batch, err := h.service.Reject(c.Request.Context(), festivalID, batchID, userID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, batch)
+}
+
+// RejectItems leaves some wallets out of a batch awaiting approval
+// @Summary Reject wallets of a batch
+// @Description Leave some wallets out of a batch awaiting approval; the others are credited or debited once it is approved. Wallets already rejected or of another batch are ignored. The reviewer must be another organizer than the creator of the batch.
+// @Tags wallet-batches
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param batchId path string true "Batch ID" format(uuid)
+// @Param request body RejectItemsRequest true "Items and reason"
+// @Success 200 {object} response.Response{data=Batch} "Batch with its rejected wallets"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Created by the reviewer"
+// @Failure 404 {object} response.ErrorResponse "Batch not found"
+// @Failure 409 {object} response.ErrorResponse "Batch not awaiting approval"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/wallet-batches/{batchId}/items/reject [post]
+func (h *Handler) RejectItems(c *gin.Context) {
+	festivalID, batchID, ok := batchParams(c)
+	if !ok {
+		return
+	}
+
+	var req RejectItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	batch, err := h.service.RejectItems(c.Request.Context(), festivalID, batchID, userID(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, batch)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBatchNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrInvalidOperation):
+		response.BadRequest(c, "INVALID_OPERATION", err.Error(), nil)
+	case errors.Is(err, ErrInvalidAmount):
+		response.BadRequest(c, "INVALID_AMOUNT", err.Error(), nil)
+	case errors.Is(err, ErrInvalidCSV):
+		response.BadRequest(c, "INVALID_FILE", err.Error(), nil)
+	case errors.Is(err, ErrTooManyRows):
+		response.BadRequest(c, "TOO_MANY_ROWS", fmt.Sprintf("A batch targets at most %d wallets", MaxRows), nil)
+	case errors.Is(err, ErrNoWallets):
+		response.UnprocessableEntity(c, err.Error(), nil)
+	case errors.Is(err, ErrNotPending):
+		response.Conflict(c, "NOT_PENDING_APPROVAL", err.Error())
+	case errors.Is(err, ErrSameReviewer):
+		response.Forbidden(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+func dryRun(c *gin.Context) bool {
+	dry, _ := strconv.ParseBool(c.Query("dryRun"))
+	return dry
+}
+
+func itemStatus(c *gin.Context) (ItemStatus, bool) {
+	status := ItemStatus(c.Query("status"))
+	switch status {
+	case "", ItemPending, ItemSucceeded, ItemFailed, ItemRejected:
+		return status, true
+	}
+	response.BadRequest(c, "INVALID_STATUS", "Status must be PENDING, SUCCEEDED, FAILED or REJECTED", nil)
+	return "", false
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	return page, perPage
+}
+
+func batchParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	batchID, err := uuid.Parse(c.Param("batchId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid batch ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return festivalID, batchID, true
+}
+
+func userID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package walletbatch
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Wallet batch errors
+var (
+	ErrBatchNotFound    = errors.New("wallet batch not found")
+	ErrInvalidOperation = errors.New("operation must be CREDIT or DEBIT")
+	ErrInvalidAmount    = errors.New("amount must be a positive number of cents")
+	ErrInvalidCSV       = errors.New("CSV file needs a header with a wallet_id or email column")
+	ErrTooManyRows      = errors.New("CSV file has too many rows")
+	ErrNoWallets        = errors.New("no wallet matches the batch")
+	ErrNotPending       = errors.New("wallet batch is not waiting for approval")
+	ErrSameReviewer     = errors.New("wallet batch must be reviewed by another organizer than its creator")
+)
+
+// MaxRows is the largest number of wallets in a batch
+const MaxRows = 50000
+
+// Operation is what a batch does to the balance of each wallet
+type Operation string
+
+const (
+	OperationCredit Operation = "CREDIT"
+	OperationDebit  Operation = "DEBIT"
+)
+
+func (o Operation) IsValid() bool {
+	return o == OperationCredit || o == OperationDebit
+}
+
+// Source is how the wallets of a batch were selected
+type Source string
+
+const (
+	SourceSegment Source = "SEGMENT"
+	SourceCSV     Source = "CSV"
+)
+
+// Status is the state of a batch
+type Status string
+
+const (
+	StatusPendingApproval Status = "PENDING_APPROVAL" // Waiting for a second organizer
+	StatusRejected        Status = "REJECTED"         // Rejected by the reviewer, nothing processed
+	StatusQueued          Status = "QUEUED"           // Chunks waiting for the worker
+	StatusProcessing      Status = "PROCESSING"       // Some chunks processed
+	StatusCompleted       Status = "COMPLETED"        // Every wallet processed, successfully or not
+)
+
+// ItemStatus is the outcome for one wallet of a batch
+type ItemStatus string
+
+const (
+	ItemPending   ItemStatus = "PENDING"
+	ItemSucceeded ItemStatus = "SUCCEEDED"
+	ItemFailed    ItemStatus = "FAILED"
+	ItemRejected  ItemStatus = "REJECTED" // Left out by the reviewer
+)
+
+// Segment selects the wallets of the attendees holding a valid ticket of the festival.
+// Every attendee wallet is selected when the segment is empty.
+type Segment struct {
+	TicketTypeIDs []uuid.UUID `json:"ticketTypeIds,omitempty"` // Holders of one of these ticket types
+	CheckedInOnly bool        `json:"checkedInOnly,omitempty"` // Attendees who entered the festival
+}
+
+// SkippedRow is a CSV row left out of a batch
+type SkippedRow struct {
+	Line   int    `json:"line"` // The header is line 1
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// Batch credits or debits many wallets of a festival at once, e.g. to compensate the
+// attendees of a cancelled show. Its wallets are processed by the worker in chunks.
+type Batch struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID  uuid.UUID    `json:"festivalId" gorm:"type:uuid;not null;index"`
+	Operation   Operation    `json:"operation" gorm:"not null"`
+	Amount      int64        `json:"amount"` // Default amount per wallet in cents
+	Reason      string       `json:"reason" gorm:"not null"`
+	Source      Source       `json:"source" gorm:"not null"`
+	Segment     *Segment     `json:"segment,omitempty" gorm:"type:jsonb;serializer:json"`
+	FileName    string       `json:"fileName,omitempty"`
+	Skipped     []SkippedRow `json:"skipped" gorm:"type:jsonb;serializer:json"`
+	Status      Status       `json:"status" gorm:"default:'QUEUED'"`
+	WalletCount int          `json:"walletCount"`
+	TotalAmount int64        `json:"totalAmount"` // Sum of the amounts in cents
+	Chunks      int          `json:"chunks"`
+	// Updated as the chunks are processed
+	Succeeded       int        `json:"succeeded"`
+	Failed          int        `json:"failed"`
+	SucceededAmount int64      `json:"succeededAmount"`
+	CreatedBy       *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	// Set on the batches above the approval threshold, reviewed by a second organizer
+	ApprovalRequired bool       `json:"approvalRequired"`
+	Rejected         int        `json:"rejected"` // Wallets left out by the reviewer
+	RejectedAmount   int64      `json:"rejectedAmount"`
+	ReviewedBy       *uuid.UUID `json:"reviewedBy,omitempty" gorm:"type:uuid"`
+	ReviewedAt       *time.Time `json:"reviewedAt,omitempty"`
+	ReviewNote       string     `json:"reviewNote,omitempty"`
+	StartedAt        *time.Time `json:"startedAt,omitempty"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+func (Batch) TableName() string {
+	return "wallet_batches"
+}
+
+// Item is the credit or debit of one wallet of a batch
+type Item struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BatchID       uuid.UUID  `json:"batchId" gorm:"type:uuid;not null;index"`
+	WalletID      uuid.UUID  `json:"walletId" gorm:"type:uuid;not null"`
+	Line          int        `json:"line,omitempty"` // CSV line, 0 for segments
+	Chunk         int        `json:"chunk"`
+	Amount        int64      `json:"amount"` // In cents, always positive
+	Status        ItemStatus `json:"status" gorm:"default:'PENDING'"`
+	TransactionID uuid.UUID  `json:"transactionId" gorm:"type:uuid;not null"` // Chosen upfront so that retries apply once
+	Error         string     `json:"error,omitempty"`
+	ProcessedAt   *time.Time `json:"processedAt,omitempty"`
+}
+
+func (Item) TableName() string {
+	return "wallet_batch_items"
+}
+
+// EventAction is a step of the review of a batch
+type EventAction string
+
+const (
+	EventCreated       EventAction = "CREATED"
+	EventItemsRejected EventAction = "ITEMS_REJECTED"
+	EventApproved      EventAction = "APPROVED"
+	EventRejected      EventAction = "REJECTED"
+)
+
+// Event records who did what to a batch awaiting approval, with the wallets and amount
+// concerned
+type Event struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BatchID   uuid.UUID   `json:"batchId" gorm:"type:uuid;not null;index"`
+	Action    EventAction `json:"action" gorm:"not null"`
+	ActorID   *uuid.UUID  `json:"actorId,omitempty" gorm:"type:uuid"`
+	Note      string      `json:"note,omitempty"`
+	Items     int         `json:"items"`
+	Amount    int64       `json:"amount"` // In cents
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+func (Event) TableName() string {
+	return "wallet_batch_events"
+}
+
+// DiffLine is the change a batch makes to one wallet, against its balance now
+type DiffLine struct {
+	ItemID        uuid.UUID  `json:"itemId"`
+	WalletID      uuid.UUID  `json:"walletId"`
+	Email         string     `json:"email,omitempty"`
+	Line          int        `json:"line,omitempty"`
+	Amount        int64      `json:"amount"` // In cents, negative for debits
+	Status        ItemStatus `json:"status"`
+	BalanceBefore int64      `json:"balanceBefore"`
+	BalanceAfter  int64      `json:"balanceAfter"`
+	Short         bool       `json:"short"` // A debit above the balance, which would fail
+}
+
+// CreateBatchRequest targets a segment of the festival attendees
+type CreateBatchRequest struct {
+	Operation Operation `json:"operation" binding:"required"`
+	Amount    int64     `json:"amount" binding:"required,min=1"` // In cents
+	Reason    string    `json:"reason" binding:"required,max=200"`
+	Segment   Segment   `json:"segment"`
+}
+
+// CSVBatchRequest targets the wallets listed in a CSV file, with an optional amount per
+// row; Amount applies to the rows without one
+type CSVBatchRequest struct {
+	Operation Operation `form:"operation" binding:"required"`
+	Amount    int64     `form:"amount" binding:"min=0"` // In cents
+	Reason    string    `form:"reason" binding:"required,max=200"`
+}
+
+// CSVRow is a wallet listed in a CSV file, by ID or by the email of its owner
+type CSVRow struct {
+	Line     int
+	WalletID *uuid.UUID
+	Email    string
+	Amount   int64 // 0 for the batch amount
+}
+
+// Target is a wallet selected by a batch, with its balance at the time
+type Target struct {
+	WalletID uuid.UUID
+	Email    string
+	Balance  int64
+	Status   string
+}
+
+// Preview is the outcome of a dry run, nothing being credited or debited
+type Preview struct {
+	Operation   Operation    `json:"operation"`
+	WalletCount int          `json:"walletCount"`
+	TotalAmount int64        `json:"totalAmount"` // In cents
+	Short       int          `json:"short"`       // Debited wallets whose balance is below the amount
+	ShortAmount int64        `json:"shortAmount"` // What those wallets lack in cents
+	Skipped     []SkippedRow `json:"skipped"`
+}
+
+// ApproveBatchRequest approves a batch awaiting approval, less its rejected wallets
+type ApproveBatchRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// RejectBatchRequest rejects a whole batch awaiting approval
+type RejectBatchRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// RejectItemsRequest leaves some wallets out of a batch awaiting approval
+type RejectItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"itemIds" binding:"required,min=1,max=1000"`
+	Reason  string      `json:"reason" binding:"required,max=500"`
+}
+
+// ItemFilter filters the listed items of a batch
+type ItemFilter struct {
+	Status ItemStatus
+	Offset int
+	Limit  int
+}
+
+// ChunkTaskPayload is the payload of the task processing a chunk of a batch
+type ChunkTaskPayload struct {
+	BatchID uuid.UUID `json:"batchId"`
+	Chunk   int       `json:"chunk"`
+}
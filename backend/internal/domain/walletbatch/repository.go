@@ -0,0 +1,393 @@
+package walletbatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// itemInsertBatch is how many items are inserted per statement
+const itemInsertBatch = 1000
+
+type Repository interface {
+	// ListSegmentWallets lists the active wallets of the attendees in a segment
+	ListSegmentWallets(ctx context.Context, festivalID uuid.UUID, segment Segment) ([]Target, error)
+	// ResolveWallets finds the wallets of the festival with one of walletIDs or owned by
+	// one of emails, whatever their status
+	ResolveWallets(ctx context.Context, festivalID uuid.UUID, walletIDs []uuid.UUID, emails []string) ([]Target, error)
+	// Create records a batch with its items, in one transaction
+	Create(ctx context.Context, batch *Batch, items []Item) error
+	Get(ctx context.Context, festivalID, id uuid.UUID) (*Batch, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Batch, error)
+	List(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Batch, int64, error)
+	// ListItems lists the items of a batch in line order, all of them when the limit is 0
+	ListItems(ctx context.Context, batchID uuid.UUID, filter ItemFilter) ([]Item, int64, error)
+	ListPendingItems(ctx context.Context, batchID uuid.UUID, chunk int) ([]Item, error)
+	UpdateItem(ctx context.Context, item *Item) error
+	// MarkStarted moves a queued batch to processing
+	MarkStarted(ctx context.Context, batchID uuid.UUID, at time.Time) error
+	// Refresh recounts the processed items of a batch, completing it once none is
+	// pending, and returns it
+	Refresh(ctx context.Context, batchID uuid.UUID, at time.Time) (*Batch, error)
+	// ListDiff lists the items of a batch with the current balance of their wallet
+	ListDiff(ctx context.Context, batchID uuid.UUID, filter ItemFilter) ([]DiffLine, int64, error)
+	// RejectItems leaves the pending items among itemIDs out of a batch awaiting approval,
+	// recording event with their count and amount, and returns the batch
+	RejectItems(ctx context.Context, batchID uuid.UUID, itemIDs []uuid.UUID, event *Event) (*Batch, error)
+	// Review records the decision on a batch awaiting approval: queued when approved, its
+	// pending items rejected when rejected. ErrNotPending when already reviewed.
+	Review(ctx context.Context, batch *Batch, event *Event) error
+	CreateEvent(ctx context.Context, event *Event) error
+	ListEvents(ctx context.Context, batchID uuid.UUID) ([]Event, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ListSegmentWallets(ctx context.Context, festivalID uuid.UUID, segment Segment) ([]Target, error) {
+	query := `
+		SELECT w.id AS wallet_id, COALESCE(u.email, '') AS email, w.balance, w.status
+		FROM wallets w
+		LEFT JOIN users u ON u.id = w.user_id
+		WHERE w.festival_id = ? AND w.status = 'ACTIVE'
+			AND EXISTS (
+				SELECT 1 FROM tickets t
+				WHERE t.festival_id = w.festival_id AND t.user_id = w.user_id
+					AND t.status NOT IN ('CANCELLED', 'TRANSFERRED')`
+	args := []interface{}{festivalID}
+
+	if len(segment.TicketTypeIDs) > 0 {
+		query += " AND t.ticket_type_id IN (?)"
+		args = append(args, segment.TicketTypeIDs)
+	}
+	if segment.CheckedInOnly {
+		query += " AND t.checked_in_at IS NOT NULL"
+	}
+	query += `)
+		ORDER BY w.created_at, w.id`
+
+	var targets []Target
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&targets).Error; err != nil {
+		return nil, fmt.Errorf("failed to list segment wallets: %w", err)
+	}
+	return targets, nil
+}
+
+func (r *repository) ResolveWallets(ctx context.Context, festivalID uuid.UUID, walletIDs []uuid.UUID, emails []string) ([]Target, error) {
+	if len(walletIDs) == 0 && len(emails) == 0 {
+		return nil, nil
+	}
+
+	query := r.db.WithContext(ctx).
+		Table("wallets w").
+		Select("w.id AS wallet_id, COALESCE(u.email, '') AS email, w.balance, w.status").
+		Joins("LEFT JOIN users u ON u.id = w.user_id").
+		Where("w.festival_id = ?", festivalID)
+
+	switch {
+	case len(walletIDs) > 0 && len(emails) > 0:
+		query = query.Where("w.id IN ? OR LOWER(u.email) IN ?", walletIDs, emails)
+	case len(walletIDs) > 0:
+		query = query.Where("w.id IN ?", walletIDs)
+	default:
+		query = query.Where("LOWER(u.email) IN ?", emails)
+	}
+
+	var targets []Target
+	if err := query.Scan(&targets).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve wallets: %w", err)
+	}
+	for i := range targets {
+		targets[i].Email = strings.ToLower(targets[i].Email)
+	}
+	return targets, nil
+}
+
+func (r *repository) Create(ctx context.Context, batch *Batch, items []Item) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return fmt.Errorf("failed to create wallet batch: %w", err)
+		}
+		if err := tx.CreateInBatches(items, itemInsertBatch).Error; err != nil {
+			return fmt.Errorf("failed to create wallet batch items: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Batch, error) {
+	var batch Batch
+	err := r.db.WithContext(ctx).Where("id = ? AND festival_id = ?", id, festivalID).First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get wallet batch: %w", err)
+	}
+	return &batch, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Batch, error) {
+	var batch Batch
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get wallet batch: %w", err)
+	}
+	return &batch, nil
+}
+
+func (r *repository) List(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Batch, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Batch{}).Where("festival_id = ?", festivalID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count wallet batches: %w", err)
+	}
+
+	var batches []Batch
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&batches).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list wallet batches: %w", err)
+	}
+	return batches, total, nil
+}
+
+func (r *repository) ListItems(ctx context.Context, batchID uuid.UUID, filter ItemFilter) ([]Item, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Item{}).Where("batch_id = ?", batchID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count wallet batch items: %w", err)
+	}
+
+	query = query.Order("chunk, line, id")
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var items []Item
+	if err := query.Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list wallet batch items: %w", err)
+	}
+	return items, total, nil
+}
+
+func (r *repository) ListPendingItems(ctx context.Context, batchID uuid.UUID, chunk int) ([]Item, error) {
+	var items []Item
+	err := r.db.WithContext(ctx).
+		Where("batch_id = ? AND chunk = ? AND status = ?", batchID, chunk, ItemPending).
+		Order("line, id").
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending wallet batch items: %w", err)
+	}
+	return items, nil
+}
+
+func (r *repository) UpdateItem(ctx context.Context, item *Item) error {
+	err := r.db.WithContext(ctx).Model(item).Updates(map[string]interface{}{
+		"status":       item.Status,
+		"error":        item.Error,
+		"processed_at": item.ProcessedAt,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update wallet batch item: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) MarkStarted(ctx context.Context, batchID uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Batch{}).
+		Where("id = ? AND status = ?", batchID, StatusQueued).
+		Updates(map[string]interface{}{
+			"status":     StatusProcessing,
+			"started_at": at,
+			"updated_at": at,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to start wallet batch: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Refresh(ctx context.Context, batchID uuid.UUID, at time.Time) (*Batch, error) {
+	// Counted from the items, so that a chunk retried by the worker is not counted twice
+	err := r.db.WithContext(ctx).Exec(`
+		WITH counts AS (
+			SELECT
+				COUNT(*) FILTER (WHERE status = 'SUCCEEDED') AS succeeded,
+				COUNT(*) FILTER (WHERE status = 'FAILED') AS failed,
+				COUNT(*) FILTER (WHERE status = 'PENDING') AS pending,
+				COALESCE(SUM(amount) FILTER (WHERE status = 'SUCCEEDED'), 0) AS succeeded_amount
+			FROM wallet_batch_items WHERE batch_id = @batch
+		)
+		UPDATE wallet_batches b SET
+			succeeded = counts.succeeded,
+			failed = counts.failed,
+			succeeded_amount = counts.succeeded_amount,
+			status = CASE WHEN counts.pending = 0 THEN 'COMPLETED' ELSE 'PROCESSING' END,
+			completed_at = CASE WHEN counts.pending = 0 THEN COALESCE(b.completed_at, @at) END,
+			updated_at = @at
+		FROM counts
+		WHERE b.id = @batch`,
+		map[string]interface{}{"batch": batchID, "at": at},
+	).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh wallet batch: %w", err)
+	}
+	return r.GetByID(ctx, batchID)
+}
+
+func (r *repository) ListDiff(ctx context.Context, batchID uuid.UUID, filter ItemFilter) ([]DiffLine, int64, error) {
+	query := r.db.WithContext(ctx).
+		Table("wallet_batch_items i").
+		Joins("INNER JOIN wallet_batches b ON b.id = i.batch_id").
+		Joins("INNER JOIN wallets w ON w.id = i.wallet_id").
+		Joins("LEFT JOIN users u ON u.id = w.user_id").
+		Where("i.batch_id = ?", batchID)
+	if filter.Status != "" {
+		query = query.Where("i.status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count wallet batch items: %w", err)
+	}
+
+	query = query.Select(`i.id AS item_id, i.wallet_id, COALESCE(u.email, '') AS email, i.line, i.status,
+		CASE WHEN b.operation = 'DEBIT' THEN -i.amount ELSE i.amount END AS amount,
+		w.balance AS balance_before`).
+		Order("i.chunk, i.line, i.id")
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var lines []DiffLine
+	if err := query.Scan(&lines).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list wallet batch diff: %w", err)
+	}
+	for i := range lines {
+		lines[i].BalanceAfter = lines[i].BalanceBefore + lines[i].Amount
+		lines[i].Short = lines[i].BalanceAfter < 0
+	}
+	return lines, total, nil
+}
+
+func (r *repository) RejectItems(ctx context.Context, batchID uuid.UUID, itemIDs []uuid.UUID, event *Event) (*Batch, error) {
+	var batch Batch
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT * FROM wallet_batches WHERE id = ? FOR UPDATE", batchID).Scan(&batch).Error; err != nil {
+			return fmt.Errorf("failed to lock wallet batch: %w", err)
+		}
+		if batch.Status != StatusPendingApproval {
+			return ErrNotPending
+		}
+
+		var amounts []int64
+		err := tx.Raw(`
+			UPDATE wallet_batch_items SET status = ?, error = ?, processed_at = ?
+			WHERE batch_id = ? AND id IN ? AND status = ?
+			RETURNING amount`,
+			ItemRejected, event.Note, event.CreatedAt, batchID, itemIDs, ItemPending,
+		).Scan(&amounts).Error
+		if err != nil {
+			return fmt.Errorf("failed to reject wallet batch items: %w", err)
+		}
+		event.Items = len(amounts)
+		for _, amount := range amounts {
+			event.Amount += amount
+		}
+
+		batch.Rejected += event.Items
+		batch.RejectedAmount += event.Amount
+		batch.UpdatedAt = event.CreatedAt
+		err = tx.Model(&Batch{}).Where("id = ?", batchID).Updates(map[string]interface{}{
+			"rejected":        batch.Rejected,
+			"rejected_amount": batch.RejectedAmount,
+			"updated_at":      batch.UpdatedAt,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update wallet batch: %w", err)
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return fmt.Errorf("failed to record wallet batch event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *repository) Review(ctx context.Context, batch *Batch, event *Event) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":      batch.Status,
+			"reviewed_by": batch.ReviewedBy,
+			"reviewed_at": batch.ReviewedAt,
+			"review_note": batch.ReviewNote,
+			"updated_at":  batch.UpdatedAt,
+		}
+		if batch.Status == StatusRejected {
+			updates["rejected"] = gorm.Expr("wallet_count")
+			updates["rejected_amount"] = gorm.Expr("total_amount")
+		}
+		result := tx.Model(&Batch{}).
+			Where("id = ? AND status = ?", batch.ID, StatusPendingApproval).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to review wallet batch: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotPending
+		}
+
+		if batch.Status == StatusRejected {
+			err := tx.Model(&Item{}).
+				Where("batch_id = ? AND status = ?", batch.ID, ItemPending).
+				Updates(map[string]interface{}{
+					"status":       ItemRejected,
+					"error":        batch.ReviewNote,
+					"processed_at": batch.ReviewedAt,
+				}).Error
+			if err != nil {
+				return fmt.Errorf("failed to reject wallet batch items: %w", err)
+			}
+		}
+
+		if err := tx.Create(event).Error; err != nil {
+			return fmt.Errorf("failed to record wallet batch event: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) CreateEvent(ctx context.Context, event *Event) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record wallet batch event: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListEvents(ctx context.Context, batchID uuid.UUID) ([]Event, error) {
+	var events []Event
+	err := r.db.WithContext(ctx).Where("batch_id = ?", batchID).Order("created_at, id").Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet batch events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,783 @@
+package walletbatch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/audit"
+	"github.com/mimi6060/festivals/backend/internal/domain/realtime"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeProcessChunk is the worker task crediting or debiting a chunk of the wallets of
+// a batch
+const TypeProcessChunk = "wallet:batch_chunk"
+
+// ChunkSize is the number of wallets processed by a task
+const ChunkSize = 200
+
+// NewProcessChunkTask creates the task processing a chunk of a batch
+func NewProcessChunkTask(batchID uuid.UUID, chunk int) (*asynq.Task, error) {
+	data, err := json.Marshal(ChunkTaskPayload{BatchID: batchID, Chunk: chunk})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeProcessChunk, data), nil
+}
+
+// Adjuster credits and debits wallets; satisfied by wallet.Service
+type Adjuster interface {
+	Adjust(ctx context.Context, walletID uuid.UUID, req wallet.AdjustRequest, staffID *uuid.UUID) (*wallet.Transaction, error)
+}
+
+// AuditLogger records the creation and review of the batches, satisfied by *audit.Service
+type AuditLogger interface {
+	LogActionAsync(ctx context.Context, req audit.CreateAuditLogRequest)
+}
+
+// Service credits or debits many wallets of a festival at once. The API previews and
+// records the batches; the worker processes their wallets in chunks. Batches above the
+// approval threshold wait for a second organizer to approve them before being queued.
+type Service struct {
+	repo              Repository
+	queueClient       *queue.Client
+	adjuster          Adjuster
+	jobs              realtime.JobBroadcaster
+	audit             AuditLogger
+	approvalThreshold int64
+	now               func() time.Time
+}
+
+// NewService creates a new wallet batch service. queueClient may be nil, in which case
+// the batches are recorded but not processed.
+func NewService(repo Repository, queueClient *queue.Client) *Service {
+	return &Service{
+		repo:        repo,
+		queueClient: queueClient,
+		now:         time.Now,
+	}
+}
+
+// SetAdjuster enables the processing of the batches
+func (s *Service) SetAdjuster(adjuster Adjuster) {
+	s.adjuster = adjuster
+}
+
+// SetJobBroadcaster reports the progress of the batches to the dashboards
+func (s *Service) SetJobBroadcaster(jobs realtime.JobBroadcaster) {
+	s.jobs = jobs
+}
+
+// SetAuditLogger records the creation and review of the batches in the audit log
+func (s *Service) SetAuditLogger(logger AuditLogger) {
+	s.audit = logger
+}
+
+// SetApprovalThreshold makes the batches totalling at least threshold cents wait for the
+// approval of another organizer than their creator; 0 queues every batch right away
+func (s *Service) SetApprovalThreshold(threshold int64) {
+	s.approvalThreshold = threshold
+}
+
+// entry is a wallet selected by a batch with its amount
+type entry struct {
+	target Target
+	line   int
+	amount int64
+}
+
+// plan is a batch before it is recorded
+type plan struct {
+	operation Operation
+	amount    int64
+	reason    string
+	source    Source
+	segment   *Segment
+	fileName  string
+	entries   []entry
+	skipped   []SkippedRow
+}
+
+// PreviewSegment totals a batch targeting a segment without crediting or debiting anything
+func (s *Service) PreviewSegment(ctx context.Context, festivalID uuid.UUID, req CreateBatchRequest) (*Preview, error) {
+	p, err := s.planSegment(ctx, festivalID, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.preview(), nil
+}
+
+// CreateFromSegment records a batch targeting a segment and queues its processing
+func (s *Service) CreateFromSegment(ctx context.Context, festivalID uuid.UUID, req CreateBatchRequest, createdBy *uuid.UUID) (*Batch, error) {
+	p, err := s.planSegment(ctx, festivalID, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, festivalID, p, createdBy)
+}
+
+// PreviewCSV totals a batch targeting the wallets of a CSV file without crediting or
+// debiting anything
+func (s *Service) PreviewCSV(ctx context.Context, festivalID uuid.UUID, req CSVBatchRequest, file io.Reader) (*Preview, error) {
+	p, err := s.planCSV(ctx, festivalID, req, "", file)
+	if err != nil {
+		return nil, err
+	}
+	return p.preview(), nil
+}
+
+// CreateFromCSV records a batch targeting the wallets of a CSV file and queues its
+// processing. Rows that do not match an active wallet of the festival are skipped.
+func (s *Service) CreateFromCSV(ctx context.Context, festivalID uuid.UUID, req CSVBatchRequest, fileName string, file io.Reader, createdBy *uuid.UUID) (*Batch, error) {
+	p, err := s.planCSV(ctx, festivalID, req, fileName, file)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, festivalID, p, createdBy)
+}
+
+func (s *Service) planSegment(ctx context.Context, festivalID uuid.UUID, req CreateBatchRequest) (*plan, error) {
+	if !req.Operation.IsValid() {
+		return nil, ErrInvalidOperation
+	}
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	targets, err := s.repo.ListSegmentWallets(ctx, festivalID, req.Segment)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+
+	segment := req.Segment
+	p := &plan{
+		operation: req.Operation,
+		amount:    req.Amount,
+		reason:    req.Reason,
+		source:    SourceSegment,
+		segment:   &segment,
+		skipped:   []SkippedRow{},
+	}
+	for _, target := range targets {
+		p.entries = append(p.entries, entry{target: target, amount: req.Amount})
+	}
+	return p, nil
+}
+
+func (s *Service) planCSV(ctx context.Context, festivalID uuid.UUID, req CSVBatchRequest, fileName string, file io.Reader) (*plan, error) {
+	if !req.Operation.IsValid() {
+		return nil, ErrInvalidOperation
+	}
+	if req.Amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	rows, skipped, err := ParseCSV(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletIDs []uuid.UUID
+	var emails []string
+	for _, row := range rows {
+		if row.WalletID != nil {
+			walletIDs = append(walletIDs, *row.WalletID)
+		} else {
+			emails = append(emails, row.Email)
+		}
+	}
+	targets, err := s.repo.ResolveWallets(ctx, festivalID, walletIDs, emails)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]Target, len(targets))
+	byEmail := make(map[string]Target, len(targets))
+	for _, target := range targets {
+		byID[target.WalletID] = target
+		if target.Email != "" {
+			byEmail[target.Email] = target
+		}
+	}
+
+	p := &plan{
+		operation: req.Operation,
+		amount:    req.Amount,
+		reason:    req.Reason,
+		source:    SourceCSV,
+		fileName:  fileName,
+		skipped:   skipped,
+	}
+	seen := make(map[uuid.UUID]int)
+	for _, row := range rows {
+		value := row.Email
+		target, found := byEmail[row.Email]
+		if row.WalletID != nil {
+			value = row.WalletID.String()
+			target, found = byID[*row.WalletID]
+		}
+
+		amount := row.Amount
+		if amount == 0 {
+			amount = req.Amount
+		}
+
+		reason := ""
+		switch {
+		case !found:
+			reason = "no wallet of the festival"
+		case target.Status != string(wallet.WalletStatusActive):
+			reason = fmt.Sprintf("wallet is %s", target.Status)
+		case seen[target.WalletID] > 0:
+			reason = fmt.Sprintf("wallet already listed on line %d", seen[target.WalletID])
+		case amount == 0:
+			reason = "no amount"
+		}
+		if reason != "" {
+			p.skipped = append(p.skipped, SkippedRow{Line: row.Line, Value: value, Reason: reason})
+			continue
+		}
+
+		seen[target.WalletID] = row.Line
+		p.entries = append(p.entries, entry{target: target, line: row.Line, amount: amount})
+	}
+	return p, nil
+}
+
+// preview totals a plan
+func (p *plan) preview() *Preview {
+	preview := &Preview{
+		Operation:   p.operation,
+		WalletCount: len(p.entries),
+		Skipped:     p.skipped,
+	}
+	for _, e := range p.entries {
+		preview.TotalAmount += e.amount
+		if p.operation == OperationDebit && e.target.Balance < e.amount {
+			preview.Short++
+			preview.ShortAmount += e.amount - e.target.Balance
+		}
+	}
+	return preview
+}
+
+// create records a plan as a batch and queues a task per chunk of its wallets, unless
+// the batch waits for approval
+func (s *Service) create(ctx context.Context, festivalID uuid.UUID, p *plan, createdBy *uuid.UUID) (*Batch, error) {
+	if len(p.entries) == 0 {
+		return nil, ErrNoWallets
+	}
+
+	now := s.now()
+	batch := &Batch{
+		ID:          uuid.New(),
+		FestivalID:  festivalID,
+		Operation:   p.operation,
+		Amount:      p.amount,
+		Reason:      p.reason,
+		Source:      p.source,
+		Segment:     p.segment,
+		FileName:    p.fileName,
+		Skipped:     p.skipped,
+		Status:      StatusQueued,
+		WalletCount: len(p.entries),
+		Chunks:      (len(p.entries) + ChunkSize - 1) / ChunkSize,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	items := make([]Item, 0, len(p.entries))
+	for i, e := range p.entries {
+		batch.TotalAmount += e.amount
+		items = append(items, Item{
+			ID:            uuid.New(),
+			BatchID:       batch.ID,
+			WalletID:      e.target.WalletID,
+			Line:          e.line,
+			Chunk:         i / ChunkSize,
+			Amount:        e.amount,
+			Status:        ItemPending,
+			TransactionID: uuid.New(),
+		})
+	}
+
+	batch.ApprovalRequired = s.approvalThreshold > 0 && batch.TotalAmount >= s.approvalThreshold
+	if batch.ApprovalRequired {
+		batch.Status = StatusPendingApproval
+	}
+
+	if err := s.repo.Create(ctx, batch, items); err != nil {
+		return nil, err
+	}
+	s.recordEvent(ctx, batch, &Event{
+		Action:  EventCreated,
+		ActorID: createdBy,
+		Note:    batch.Reason,
+		Items:   batch.WalletCount,
+		Amount:  batch.TotalAmount,
+	})
+
+	if !batch.ApprovalRequired {
+		s.queue(ctx, batch)
+	}
+	return batch, nil
+}
+
+// queue queues a task per chunk of the wallets of a batch
+func (s *Service) queue(ctx context.Context, batch *Batch) {
+	if s.queueClient == nil {
+		return
+	}
+	for chunk := 0; chunk < batch.Chunks; chunk++ {
+		task, err := NewProcessChunkTask(batch.ID, chunk)
+		if err != nil {
+			log.Error().Err(err).Str("batch_id", batch.ID.String()).Msg("Failed to create wallet batch chunk task")
+			continue
+		}
+		if _, err := s.queueClient.EnqueueCritical(ctx, task,
+			asynq.TaskID(fmt.Sprintf("wallet_batch_%s_%d", batch.ID, chunk)),
+			asynq.MaxRetry(5),
+		); err != nil {
+			// The wallets of the chunk stay pending in the report
+			log.Error().Err(err).
+				Str("batch_id", batch.ID.String()).
+				Int("chunk", chunk).
+				Msg("Failed to queue wallet batch chunk")
+		}
+	}
+	s.trackJob(batch).Queued(ctx)
+}
+
+// Approve queues a batch awaiting approval, less the wallets rejected by the reviewer.
+// The reviewer must be another organizer than the creator of the batch.
+func (s *Service) Approve(ctx context.Context, festivalID, id uuid.UUID, reviewerID *uuid.UUID, req ApproveBatchRequest) (*Batch, error) {
+	batch, err := s.pendingBatch(ctx, festivalID, id, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Rejected >= batch.WalletCount {
+		return nil, ErrNoWallets
+	}
+
+	now := s.now()
+	batch.Status = StatusQueued
+	batch.ReviewedBy = reviewerID
+	batch.ReviewedAt = &now
+	batch.ReviewNote = req.Note
+	batch.UpdatedAt = now
+	event := &Event{
+		ID:        uuid.New(),
+		BatchID:   batch.ID,
+		Action:    EventApproved,
+		ActorID:   reviewerID,
+		Note:      req.Note,
+		Items:     batch.WalletCount - batch.Rejected,
+		Amount:    batch.TotalAmount - batch.RejectedAmount,
+		CreatedAt: now,
+	}
+	if err := s.repo.Review(ctx, batch, event); err != nil {
+		return nil, err
+	}
+	s.auditEvent(ctx, batch, event)
+
+	s.queue(ctx, batch)
+	return batch, nil
+}
+
+// Reject rejects a whole batch awaiting approval: none of its wallets is credited or
+// debited. Its creator may withdraw it this way.
+func (s *Service) Reject(ctx context.Context, festivalID, id uuid.UUID, reviewerID *uuid.UUID, req RejectBatchRequest) (*Batch, error) {
+	batch, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != StatusPendingApproval {
+		return nil, ErrNotPending
+	}
+
+	now := s.now()
+	event := &Event{
+		ID:        uuid.New(),
+		BatchID:   batch.ID,
+		Action:    EventRejected,
+		ActorID:   reviewerID,
+		Note:      req.Reason,
+		Items:     batch.WalletCount - batch.Rejected,
+		Amount:    batch.TotalAmount - batch.RejectedAmount,
+		CreatedAt: now,
+	}
+	batch.Status = StatusRejected
+	batch.ReviewedBy = reviewerID
+	batch.ReviewedAt = &now
+	batch.ReviewNote = req.Reason
+	batch.Rejected = batch.WalletCount
+	batch.RejectedAmount = batch.TotalAmount
+	batch.UpdatedAt = now
+	if err := s.repo.Review(ctx, batch, event); err != nil {
+		return nil, err
+	}
+	s.auditEvent(ctx, batch, event)
+	return batch, nil
+}
+
+// RejectItems leaves some wallets out of a batch awaiting approval, the others being
+// credited or debited once it is approved. Items already rejected or of another batch
+// are ignored.
+func (s *Service) RejectItems(ctx context.Context, festivalID, id uuid.UUID, reviewerID *uuid.UUID, req RejectItemsRequest) (*Batch, error) {
+	if _, err := s.pendingBatch(ctx, festivalID, id, reviewerID); err != nil {
+		return nil, err
+	}
+
+	event := &Event{
+		ID:        uuid.New(),
+		BatchID:   id,
+		Action:    EventItemsRejected,
+		ActorID:   reviewerID,
+		Note:      req.Reason,
+		CreatedAt: s.now(),
+	}
+	batch, err := s.repo.RejectItems(ctx, id, req.ItemIDs, event)
+	if err != nil {
+		return nil, err
+	}
+	s.auditEvent(ctx, batch, event)
+	return batch, nil
+}
+
+// pendingBatch returns a batch of the festival awaiting approval that reviewerID may
+// review
+func (s *Service) pendingBatch(ctx context.Context, festivalID, id uuid.UUID, reviewerID *uuid.UUID) (*Batch, error) {
+	batch, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != StatusPendingApproval {
+		return nil, ErrNotPending
+	}
+	if reviewerID == nil || (batch.CreatedBy != nil && *batch.CreatedBy == *reviewerID) {
+		return nil, ErrSameReviewer
+	}
+	return batch, nil
+}
+
+// recordEvent records an event of a batch in its history and the audit log. A failure
+// is logged: the batch itself is already recorded.
+func (s *Service) recordEvent(ctx context.Context, batch *Batch, event *Event) {
+	event.ID = uuid.New()
+	event.BatchID = batch.ID
+	event.CreatedAt = s.now()
+	if err := s.repo.CreateEvent(ctx, event); err != nil {
+		log.Error().Err(err).Str("batch_id", batch.ID.String()).Msg("Failed to record wallet batch event")
+	}
+	s.auditEvent(ctx, batch, event)
+}
+
+// auditEvent records an event of a batch in the audit log
+func (s *Service) auditEvent(ctx context.Context, batch *Batch, event *Event) {
+	if s.audit == nil {
+		return
+	}
+	action := audit.ActionWalletBatchCreate
+	switch event.Action {
+	case EventApproved:
+		action = audit.ActionWalletBatchApprove
+	case EventRejected, EventItemsRejected:
+		action = audit.ActionWalletBatchReject
+	}
+	festivalID := batch.FestivalID
+	s.audit.LogActionAsync(ctx, audit.CreateAuditLogRequest{
+		UserID:     event.ActorID,
+		Action:     action,
+		Resource:   "wallet_batch",
+		ResourceID: batch.ID.String(),
+		FestivalID: &festivalID,
+		Metadata: map[string]interface{}{
+			"event":             event.Action,
+			"note":              event.Note,
+			"items":             event.Items,
+			"amount":            event.Amount,
+			"operation":         batch.Operation,
+			"status":            batch.Status,
+			"approval_required": batch.ApprovalRequired,
+		},
+	})
+}
+
+// HandleProcessChunk handles a chunk task queued by a batch
+func (s *Service) HandleProcessChunk(ctx context.Context, t *asynq.Task) error {
+	var payload ChunkTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return s.ProcessChunk(ctx, payload.BatchID, payload.Chunk)
+}
+
+// ProcessChunk credits or debits the pending wallets of a chunk. A wallet that cannot
+// be credited or debited, e.g. a debit above its balance, is recorded as failed
+// without stopping the others. A chunk retried after a failure does not credit or
+// debit its wallets twice.
+func (s *Service) ProcessChunk(ctx context.Context, batchID uuid.UUID, chunk int) error {
+	if s.adjuster == nil {
+		return fmt.Errorf("wallet adjustments are not available")
+	}
+
+	batch, err := s.repo.GetByID(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if batch == nil {
+		return nil
+	}
+
+	// Only approved batches are queued, a task of another one is stale
+	if batch.Status == StatusPendingApproval || batch.Status == StatusRejected {
+		return nil
+	}
+
+	job := s.trackJob(batch)
+	if batch.Status == StatusQueued {
+		if err := s.repo.MarkStarted(ctx, batch.ID, s.now()); err != nil {
+			return err
+		}
+		job.Started(ctx)
+	}
+
+	items, err := s.repo.ListPendingItems(ctx, batch.ID, chunk)
+	if err != nil {
+		return err
+	}
+
+	sign := int64(1)
+	if batch.Operation == OperationDebit {
+		sign = -1
+	}
+	for i := range items {
+		item := &items[i]
+		_, err := s.adjuster.Adjust(ctx, item.WalletID, wallet.AdjustRequest{
+			TransactionID: item.TransactionID,
+			Amount:        sign * item.Amount,
+			Reason:        batch.Reason,
+			Reference:     "wallet_batch:" + batch.ID.String(),
+		}, batch.CreatedBy)
+
+		processedAt := s.now()
+		item.ProcessedAt = &processedAt
+		if err != nil && !errors.Is(err, wallet.ErrAdjustmentApplied) {
+			item.Status = ItemFailed
+			item.Error = err.Error()
+		} else {
+			item.Status = ItemSucceeded
+		}
+		if err := s.repo.UpdateItem(ctx, item); err != nil {
+			return err
+		}
+	}
+
+	batch, err = s.repo.Refresh(ctx, batch.ID, s.now())
+	if err != nil {
+		return err
+	}
+	job.Progress(ctx, batch.Succeeded+batch.Failed, batch.WalletCount)
+	if batch.Status == StatusCompleted {
+		job.Completed(ctx, fmt.Sprintf("/api/v1/festivals/%s/wallet-batches/%s/report", batch.FestivalID, batch.ID))
+		log.Info().
+			Str("batch_id", batch.ID.String()).
+			Int("succeeded", batch.Succeeded).
+			Int("failed", batch.Failed).
+			Msg("Processed wallet batch")
+	}
+	return nil
+}
+
+// trackJob tracks a batch for the jobs panel of the dashboard
+func (s *Service) trackJob(batch *Batch) *realtime.JobTracker {
+	title := fmt.Sprintf("Wallet %s: %s", strings.ToLower(string(batch.Operation)), batch.Reason)
+	return realtime.TrackJob(s.jobs, batch.FestivalID.String(), batch.ID.String(), realtime.JobKindWalletBatch, title)
+}
+
+// Get returns a batch of the festival
+func (s *Service) Get(ctx context.Context, festivalID, id uuid.UUID) (*Batch, error) {
+	batch, err := s.repo.Get(ctx, festivalID, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, ErrBatchNotFound
+	}
+	return batch, nil
+}
+
+// List lists the batches of the festival, latest first
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Batch, int64, error) {
+	return s.repo.List(ctx, festivalID, offset, limit)
+}
+
+// ListItems lists the wallets of a batch with their outcome
+func (s *Service) ListItems(ctx context.Context, festivalID, id uuid.UUID, filter ItemFilter) ([]Item, int64, error) {
+	if _, err := s.Get(ctx, festivalID, id); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListItems(ctx, id, filter)
+}
+
+// Diff lists the change a batch makes to each of its wallets against their balance now,
+// for its reviewer to check before approving it
+func (s *Service) Diff(ctx context.Context, festivalID, id uuid.UUID, filter ItemFilter) ([]DiffLine, int64, error) {
+	if _, err := s.Get(ctx, festivalID, id); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDiff(ctx, id, filter)
+}
+
+// ListEvents lists the history of a batch, oldest first
+func (s *Service) ListEvents(ctx context.Context, festivalID, id uuid.UUID) ([]Event, error) {
+	if _, err := s.Get(ctx, festivalID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListEvents(ctx, id)
+}
+
+// WriteReport writes the outcome for each wallet of a batch as CSV, followed by the
+// skipped rows of its file. Amounts are in cents, negative for debits.
+func (s *Service) WriteReport(ctx context.Context, festivalID, id uuid.UUID, w io.Writer) error {
+	batch, err := s.Get(ctx, festivalID, id)
+	if err != nil {
+		return err
+	}
+	items, _, err := s.repo.ListItems(ctx, id, ItemFilter{})
+	if err != nil {
+		return err
+	}
+
+	sign := int64(1)
+	if batch.Operation == OperationDebit {
+		sign = -1
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"line", "wallet", "amount", "status", "transaction_id", "error", "processed_at"}); err != nil {
+		return err
+	}
+	for _, item := range items {
+		line, processedAt, transactionID := "", "", ""
+		if item.Line > 0 {
+			line = strconv.Itoa(item.Line)
+		}
+		if item.ProcessedAt != nil {
+			processedAt = item.ProcessedAt.UTC().Format(time.RFC3339)
+		}
+		if item.Status == ItemSucceeded {
+			transactionID = item.TransactionID.String()
+		}
+		if err := writer.Write([]string{
+			line, item.WalletID.String(), strconv.FormatInt(sign*item.Amount, 10), string(item.Status),
+			transactionID, item.Error, processedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, row := range batch.Skipped {
+		if err := writer.Write([]string{strconv.Itoa(row.Line), row.Value, "", "SKIPPED", "", row.Reason, ""}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ParseCSV reads the wallets listed in a CSV file. The header names a wallet_id or an
+// email column, and optionally an amount column in cents. Rows that cannot be read are
+// returned as skipped.
+func ParseCSV(r io.Reader) ([]CSVRow, []SkippedRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, ErrInvalidCSV
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	walletCol, hasWallet := columns["wallet_id"]
+	emailCol, hasEmail := columns["email"]
+	amountCol, hasAmount := columns["amount"]
+	if !hasWallet && !hasEmail {
+		return nil, nil, ErrInvalidCSV
+	}
+
+	field := func(record []string, col int, ok bool) string {
+		if !ok || col >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[col])
+	}
+
+	var rows []CSVRow
+	skipped := []SkippedRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			skipped = append(skipped, SkippedRow{Line: line, Reason: "unreadable row"})
+			continue
+		}
+		if len(rows)+len(skipped) >= MaxRows {
+			return nil, nil, ErrTooManyRows
+		}
+
+		row := CSVRow{Line: line}
+		rawWallet := field(record, walletCol, hasWallet)
+		rawEmail := field(record, emailCol, hasEmail)
+		switch {
+		case rawWallet != "":
+			id, err := uuid.Parse(rawWallet)
+			if err != nil {
+				skipped = append(skipped, SkippedRow{Line: line, Value: rawWallet, Reason: "invalid wallet ID"})
+				continue
+			}
+			row.WalletID = &id
+		case rawEmail != "":
+			row.Email = strings.ToLower(rawEmail)
+		default:
+			if strings.TrimSpace(strings.Join(record, "")) != "" {
+				skipped = append(skipped, SkippedRow{Line: line, Reason: "no wallet ID or email"})
+			}
+			continue
+		}
+
+		if rawAmount := field(record, amountCol, hasAmount); rawAmount != "" {
+			amount, err := strconv.ParseInt(rawAmount, 10, 64)
+			if err != nil || amount <= 0 {
+				value := rawWallet
+				if value == "" {
+					value = row.Email
+				}
+				skipped = append(skipped, SkippedRow{Line: line, Value: value, Reason: "invalid amount " + rawAmount})
+				continue
+			}
+			row.Amount = amount
+		}
+		rows = append(rows, row)
+	}
+	return rows, skipped, nil
+}
@@ -0,0 +1,450 @@
+package walletbatch
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	segment []Target
+	wallets []Target
+	batch   *Batch
+	items   []Item
+	events  []Event
+}
+
+func (r *fakeRepository) ListSegmentWallets(ctx context.Context, festivalID uuid.UUID, segment Segment) ([]Target, error) {
+	return r.segment, nil
+}
+
+func (r *fakeRepository) ResolveWallets(ctx context.Context, festivalID uuid.UUID, walletIDs []uuid.UUID, emails []string) ([]Target, error) {
+	return r.wallets, nil
+}
+
+func (r *fakeRepository) Create(ctx context.Context, batch *Batch, items []Item) error {
+	stored := *batch
+	r.batch = &stored
+	r.items = items
+	return nil
+}
+
+func (r *fakeRepository) Get(ctx context.Context, festivalID, id uuid.UUID) (*Batch, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeRepository) GetByID(ctx context.Context, id uuid.UUID) (*Batch, error) {
+	if r.batch == nil {
+		return nil, nil
+	}
+	found := *r.batch
+	return &found, nil
+}
+
+func (r *fakeRepository) List(ctx context.Context, festivalID uuid.UUID, offset, limit int) ([]Batch, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRepository) ListItems(ctx context.Context, batchID uuid.UUID, filter ItemFilter) ([]Item, int64, error) {
+	return r.items, int64(len(r.items)), nil
+}
+
+func (r *fakeRepository) ListPendingItems(ctx context.Context, batchID uuid.UUID, chunk int) ([]Item, error) {
+	var items []Item
+	for _, item := range r.items {
+		if item.Chunk == chunk && item.Status == ItemPending {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeRepository) UpdateItem(ctx context.Context, item *Item) error {
+	for i := range r.items {
+		if r.items[i].ID == item.ID {
+			r.items[i] = *item
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) MarkStarted(ctx context.Context, batchID uuid.UUID, at time.Time) error {
+	r.batch.Status = StatusProcessing
+	return nil
+}
+
+func (r *fakeRepository) Refresh(ctx context.Context, batchID uuid.UUID, at time.Time) (*Batch, error) {
+	r.batch.Succeeded, r.batch.Failed, r.batch.SucceededAmount = 0, 0, 0
+	pending := 0
+	for _, item := range r.items {
+		switch item.Status {
+		case ItemSucceeded:
+			r.batch.Succeeded++
+			r.batch.SucceededAmount += item.Amount
+		case ItemFailed:
+			r.batch.Failed++
+		case ItemPending:
+			pending++
+		}
+	}
+	if pending == 0 {
+		r.batch.Status = StatusCompleted
+	}
+	return r.batch, nil
+}
+
+func (r *fakeRepository) ListDiff(ctx context.Context, batchID uuid.UUID, filter ItemFilter) ([]DiffLine, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRepository) RejectItems(ctx context.Context, batchID uuid.UUID, itemIDs []uuid.UUID, event *Event) (*Batch, error) {
+	if r.batch.Status != StatusPendingApproval {
+		return nil, ErrNotPending
+	}
+	rejected := make(map[uuid.UUID]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		rejected[id] = true
+	}
+	for i := range r.items {
+		if rejected[r.items[i].ID] && r.items[i].Status == ItemPending {
+			r.items[i].Status = ItemRejected
+			r.items[i].Error = event.Note
+			event.Items++
+			event.Amount += r.items[i].Amount
+		}
+	}
+	r.batch.Rejected += event.Items
+	r.batch.RejectedAmount += event.Amount
+	r.events = append(r.events, *event)
+	return r.batch, nil
+}
+
+func (r *fakeRepository) Review(ctx context.Context, batch *Batch, event *Event) error {
+	if r.batch.Status != StatusPendingApproval {
+		return ErrNotPending
+	}
+	if batch.Status == StatusRejected {
+		for i := range r.items {
+			if r.items[i].Status == ItemPending {
+				r.items[i].Status = ItemRejected
+			}
+		}
+	}
+	reviewed := *batch
+	r.batch = &reviewed
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeRepository) CreateEvent(ctx context.Context, event *Event) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeRepository) ListEvents(ctx context.Context, batchID uuid.UUID) ([]Event, error) {
+	return r.events, nil
+}
+
+type fakeAdjuster struct {
+	failing map[uuid.UUID]bool
+	applied map[uuid.UUID]int64
+}
+
+func (a *fakeAdjuster) Adjust(ctx context.Context, walletID uuid.UUID, req wallet.AdjustRequest, staffID *uuid.UUID) (*wallet.Transaction, error) {
+	if a.failing[walletID] {
+		return nil, wallet.ErrAdjustmentInsufficient
+	}
+	if _, ok := a.applied[req.TransactionID]; ok {
+		return nil, wallet.ErrAdjustmentApplied
+	}
+	a.applied[req.TransactionID] = req.Amount
+	return &wallet.Transaction{ID: req.TransactionID, WalletID: walletID, Amount: req.Amount}, nil
+}
+
+func activeTarget(email string, balance int64) Target {
+	return Target{WalletID: uuid.New(), Email: email, Balance: balance, Status: string(wallet.WalletStatusActive)}
+}
+
+func TestParseCSV(t *testing.T) {
+	walletID := uuid.New()
+	file := "\ufeffWallet_ID,Email,Amount\n" +
+		walletID.String() + ",,500\n" +
+		",Alice@Example.com,\n" +
+		"not-a-uuid,,100\n" +
+		",bob@example.com,-3\n" +
+		",,\n" +
+		",carol@example.com,250\n"
+
+	rows, skipped, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, walletID, *rows[0].WalletID)
+	assert.Equal(t, int64(500), rows[0].Amount)
+	assert.Equal(t, "alice@example.com", rows[1].Email)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.Zero(t, rows[1].Amount)
+	assert.Equal(t, int64(250), rows[2].Amount)
+
+	require.Len(t, skipped, 2)
+	assert.Equal(t, 4, skipped[0].Line)
+	assert.Equal(t, "invalid wallet ID", skipped[0].Reason)
+	assert.Equal(t, 5, skipped[1].Line)
+	assert.Equal(t, "bob@example.com", skipped[1].Value)
+}
+
+func TestParseCSV_MissingColumn(t *testing.T) {
+	_, _, err := ParseCSV(strings.NewReader("name,amount\nAlice,100\n"))
+	assert.ErrorIs(t, err, ErrInvalidCSV)
+}
+
+func TestPreviewCSV_SkipsUnknownInactiveAndDuplicateWallets(t *testing.T) {
+	alice := activeTarget("alice@example.com", 1000)
+	frozen := activeTarget("frozen@example.com", 1000)
+	frozen.Status = string(wallet.WalletStatusFrozen)
+	repo := &fakeRepository{wallets: []Target{alice, frozen}}
+	svc := NewService(repo, nil)
+
+	file := "email,wallet_id\n" +
+		"alice@example.com,\n" +
+		"frozen@example.com,\n" +
+		"unknown@example.com,\n" +
+		"," + alice.WalletID.String() + "\n"
+
+	preview, err := svc.PreviewCSV(context.Background(), uuid.New(), CSVBatchRequest{
+		Operation: OperationCredit,
+		Amount:    500,
+		Reason:    "Cancelled show",
+	}, strings.NewReader(file))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, preview.WalletCount)
+	assert.Equal(t, int64(500), preview.TotalAmount)
+	require.Len(t, preview.Skipped, 3)
+	assert.Equal(t, "wallet is FROZEN", preview.Skipped[0].Reason)
+	assert.Equal(t, "no wallet of the festival", preview.Skipped[1].Reason)
+	assert.Equal(t, "wallet already listed on line 2", preview.Skipped[2].Reason)
+}
+
+func TestPreviewSegment_CountsShortDebits(t *testing.T) {
+	repo := &fakeRepository{segment: []Target{
+		activeTarget("alice@example.com", 1000),
+		activeTarget("bob@example.com", 200),
+	}}
+	svc := NewService(repo, nil)
+
+	preview, err := svc.PreviewSegment(context.Background(), uuid.New(), CreateBatchRequest{
+		Operation: OperationDebit,
+		Amount:    500,
+		Reason:    "Deposit",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, preview.WalletCount)
+	assert.Equal(t, int64(1000), preview.TotalAmount)
+	assert.Equal(t, 1, preview.Short)
+	assert.Equal(t, int64(300), preview.ShortAmount)
+	assert.Nil(t, repo.batch, "a dry run records nothing")
+}
+
+func TestCreateFromSegment_SplitsIntoChunks(t *testing.T) {
+	repo := &fakeRepository{}
+	for i := 0; i < ChunkSize+1; i++ {
+		repo.segment = append(repo.segment, activeTarget("", 0))
+	}
+	svc := NewService(repo, nil)
+
+	batch, err := svc.CreateFromSegment(context.Background(), uuid.New(), CreateBatchRequest{
+		Operation: OperationCredit,
+		Amount:    100,
+		Reason:    "Cancelled show",
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusQueued, batch.Status)
+	assert.Equal(t, ChunkSize+1, batch.WalletCount)
+	assert.Equal(t, 2, batch.Chunks)
+	assert.Equal(t, int64(100*(ChunkSize+1)), batch.TotalAmount)
+	assert.Equal(t, 1, repo.items[ChunkSize].Chunk)
+}
+
+func TestCreateFromSegment_NoWallets(t *testing.T) {
+	svc := NewService(&fakeRepository{}, nil)
+
+	_, err := svc.CreateFromSegment(context.Background(), uuid.New(), CreateBatchRequest{
+		Operation: OperationCredit,
+		Amount:    100,
+		Reason:    "Cancelled show",
+	}, nil)
+	assert.ErrorIs(t, err, ErrNoWallets)
+}
+
+func TestProcessChunk_RecordsFailuresAndAppliesOnce(t *testing.T) {
+	alice := activeTarget("alice@example.com", 1000)
+	bob := activeTarget("bob@example.com", 100)
+	repo := &fakeRepository{segment: []Target{alice, bob}}
+	adjuster := &fakeAdjuster{failing: map[uuid.UUID]bool{bob.WalletID: true}, applied: map[uuid.UUID]int64{}}
+	svc := NewService(repo, nil)
+	svc.SetAdjuster(adjuster)
+
+	batch, err := svc.CreateFromSegment(context.Background(), uuid.New(), CreateBatchRequest{
+		Operation: OperationDebit,
+		Amount:    500,
+		Reason:    "Deposit",
+	}, nil)
+	require.NoError(t, err)
+
+	// Alice was debited before a crash, so the retried chunk must not debit her again
+	adjuster.applied[repo.items[0].TransactionID] = -500
+
+	require.NoError(t, svc.ProcessChunk(context.Background(), batch.ID, 0))
+
+	assert.Len(t, adjuster.applied, 1)
+	assert.Equal(t, StatusCompleted, repo.batch.Status)
+	assert.Equal(t, 1, repo.batch.Succeeded)
+	assert.Equal(t, 1, repo.batch.Failed)
+	assert.Equal(t, int64(500), repo.batch.SucceededAmount)
+	assert.Equal(t, ItemFailed, repo.items[1].Status)
+	assert.Equal(t, wallet.ErrAdjustmentInsufficient.Error(), repo.items[1].Error)
+
+	var report bytes.Buffer
+	require.NoError(t, svc.WriteReport(context.Background(), batch.FestivalID, batch.ID, &report))
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[1], ",-500,SUCCEEDED,")
+	assert.Contains(t, lines[2], ",-500,FAILED,,")
+}
+
+func TestProcessChunk_WithoutAdjuster(t *testing.T) {
+	svc := NewService(&fakeRepository{}, nil)
+
+	assert.Error(t, svc.ProcessChunk(context.Background(), uuid.New(), 0))
+}
+
+func newApprovalBatch(t *testing.T, repo *fakeRepository, svc *Service, maker uuid.UUID) *Batch {
+	t.Helper()
+	batch, err := svc.CreateFromSegment(context.Background(), uuid.New(), CreateBatchRequest{
+		Operation: OperationCredit,
+		Amount:    2500,
+		Reason:    "Cancelled headliner",
+	}, &maker)
+	require.NoError(t, err)
+	return batch
+}
+
+func TestCreateFromSegment_AboveThresholdWaitsForApproval(t *testing.T) {
+	repo := &fakeRepository{segment: []Target{activeTarget("", 0), activeTarget("", 0)}}
+	svc := NewService(repo, nil)
+	svc.SetApprovalThreshold(5000)
+	svc.SetAdjuster(&fakeAdjuster{applied: map[uuid.UUID]int64{}})
+
+	batch := newApprovalBatch(t, repo, svc, uuid.New())
+
+	assert.Equal(t, StatusPendingApproval, batch.Status)
+	assert.True(t, batch.ApprovalRequired)
+	require.Len(t, repo.events, 1)
+	assert.Equal(t, EventCreated, repo.events[0].Action)
+	assert.Equal(t, int64(5000), repo.events[0].Amount)
+
+	// A chunk task of a batch awaiting approval processes nothing
+	require.NoError(t, svc.ProcessChunk(context.Background(), batch.ID, 0))
+	assert.Equal(t, ItemPending, repo.items[0].Status)
+}
+
+func TestCreateFromSegment_BelowThresholdQueued(t *testing.T) {
+	repo := &fakeRepository{segment: []Target{activeTarget("", 0)}}
+	svc := NewService(repo, nil)
+	svc.SetApprovalThreshold(5000)
+
+	batch := newApprovalBatch(t, repo, svc, uuid.New())
+
+	assert.Equal(t, StatusQueued, batch.Status)
+	assert.False(t, batch.ApprovalRequired)
+}
+
+func TestApprove_ByAnotherOrganizer(t *testing.T) {
+	repo := &fakeRepository{segment: []Target{activeTarget("", 0), activeTarget("", 0), activeTarget("", 0)}}
+	adjuster := &fakeAdjuster{applied: map[uuid.UUID]int64{}}
+	svc := NewService(repo, nil)
+	svc.SetApprovalThreshold(5000)
+	svc.SetAdjuster(adjuster)
+	maker, checker := uuid.New(), uuid.New()
+	batch := newApprovalBatch(t, repo, svc, maker)
+	ctx := context.Background()
+
+	_, err := svc.Approve(ctx, batch.FestivalID, batch.ID, &maker, ApproveBatchRequest{})
+	assert.ErrorIs(t, err, ErrSameReviewer)
+	_, err = svc.RejectItems(ctx, batch.FestivalID, batch.ID, &maker, RejectItemsRequest{ItemIDs: []uuid.UUID{repo.items[0].ID}, Reason: "Refunded at the desk"})
+	assert.ErrorIs(t, err, ErrSameReviewer)
+	_, err = svc.Approve(ctx, batch.FestivalID, batch.ID, nil, ApproveBatchRequest{})
+	assert.ErrorIs(t, err, ErrSameReviewer)
+
+	batch, err = svc.RejectItems(ctx, batch.FestivalID, batch.ID, &checker, RejectItemsRequest{
+		ItemIDs: []uuid.UUID{repo.items[1].ID, uuid.New()},
+		Reason:  "Refunded at the desk",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, batch.Rejected)
+	assert.Equal(t, int64(2500), batch.RejectedAmount)
+
+	batch, err = svc.Approve(ctx, batch.FestivalID, batch.ID, &checker, ApproveBatchRequest{Note: "Checked against the ticket list"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, batch.Status)
+	assert.Equal(t, &checker, batch.ReviewedBy)
+
+	require.NoError(t, svc.ProcessChunk(ctx, batch.ID, 0))
+	assert.Len(t, adjuster.applied, 2)
+	assert.Equal(t, ItemRejected, repo.items[1].Status)
+	assert.Equal(t, StatusCompleted, repo.batch.Status)
+
+	require.Len(t, repo.events, 3)
+	assert.Equal(t, EventItemsRejected, repo.events[1].Action)
+	assert.Equal(t, EventApproved, repo.events[2].Action)
+	assert.Equal(t, 2, repo.events[2].Items)
+	assert.Equal(t, int64(5000), repo.events[2].Amount)
+
+	_, err = svc.Approve(ctx, batch.FestivalID, batch.ID, &checker, ApproveBatchRequest{})
+	assert.ErrorIs(t, err, ErrNotPending)
+}
+
+func TestApprove_EveryWalletRejected(t *testing.T) {
+	repo := &fakeRepository{segment: []Target{activeTarget("", 0), activeTarget("", 0)}}
+	svc := NewService(repo, nil)
+	svc.SetApprovalThreshold(5000)
+	checker := uuid.New()
+	batch := newApprovalBatch(t, repo, svc, uuid.New())
+	ctx := context.Background()
+
+	_, err := svc.RejectItems(ctx, batch.FestivalID, batch.ID, &checker, RejectItemsRequest{
+		ItemIDs: []uuid.UUID{repo.items[0].ID, repo.items[1].ID},
+		Reason:  "Duplicates",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Approve(ctx, batch.FestivalID, batch.ID, &checker, ApproveBatchRequest{})
+	assert.ErrorIs(t, err, ErrNoWallets)
+}
+
+func TestReject_WithdrawnByItsCreator(t *testing.T) {
+	repo := &fakeRepository{segment: []Target{activeTarget("", 0), activeTarget("", 0)}}
+	svc := NewService(repo, nil)
+	svc.SetApprovalThreshold(5000)
+	maker := uuid.New()
+	batch := newApprovalBatch(t, repo, svc, maker)
+
+	batch, err := svc.Reject(context.Background(), batch.FestivalID, batch.ID, &maker, RejectBatchRequest{Reason: "Wrong amount"})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusRejected, batch.Status)
+	assert.Equal(t, 2, batch.Rejected)
+	assert.Equal(t, int64(5000), batch.RejectedAmount)
+	assert.Equal(t, ItemRejected, repo.items[0].Status)
+	assert.Equal(t, EventRejected, repo.events[len(repo.events)-1].Action)
+}
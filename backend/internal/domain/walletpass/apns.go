@@ -0,0 +1,93 @@
+package walletpass
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// APNsURL is the production APNs endpoint, the only one Apple Wallet passes use
+const APNsURL = "https://api.push.apple.com"
+
+// errPushTokenGone is returned when APNs no longer accepts a push token, i.e. the pass
+// was removed from the device
+var errPushTokenGone = errors.New("push token is no longer valid")
+
+// apnsClient asks devices to fetch updated passes. Pass pushes are empty
+// notifications authenticated with the pass type certificate, whose topic is the pass
+// type identifier.
+type apnsClient struct {
+	baseURL string
+	timeout time.Duration
+
+	// newClient is replaced in tests
+	newClient func(cert tls.Certificate) *http.Client
+}
+
+func newAPNsClient() *apnsClient {
+	c := &apnsClient{baseURL: APNsURL, timeout: 30 * time.Second}
+	c.newClient = func(cert tls.Certificate) *http.Client {
+		return &http.Client{
+			Transport: &http2.Transport{
+				TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+			},
+			Timeout: c.timeout,
+		}
+	}
+	return c
+}
+
+// push notifies the devices behind the push tokens, returning the tokens APNs
+// reported as gone
+func (c *apnsClient) push(ctx context.Context, cert tls.Certificate, passTypeID string, pushTokens []string) ([]string, error) {
+	client := c.newClient(cert)
+
+	var gone []string
+	var errs []string
+	for _, token := range pushTokens {
+		err := c.send(ctx, client, passTypeID, token)
+		switch {
+		case errors.Is(err, errPushTokenGone):
+			gone = append(gone, token)
+		case err != nil:
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return gone, fmt.Errorf("failed to push %d of %d devices: %s", len(errs), len(pushTokens), errs[0])
+	}
+	return gone, nil
+}
+
+func (c *apnsClient) send(ctx context.Context, client *http.Client, passTypeID, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/3/device/"+token, strings.NewReader("{}"))
+	if err != nil {
+		return fmt.Errorf("failed to create APNs request: %w", err)
+	}
+	req.Header.Set("apns-topic", passTypeID)
+	req.Header.Set("apns-push-type", "background")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send pass push: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusGone:
+		return errPushTokenGone
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("APNs error (status %d): %s", resp.StatusCode, string(body))
+	}
+}
@@ -0,0 +1,367 @@
+package walletpass
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+// oidUserID is the subject attribute holding the pass type identifier of an Apple pass
+// type certificate
+var oidUserID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+// UploadCertificate validates and stores signing credentials, encrypting the private
+// key. The first certificate of a platform is activated automatically.
+func (s *Service) UploadCertificate(ctx context.Context, req UploadCertificateRequest, createdBy *uuid.UUID) (*CertificateResponse, error) {
+	if !req.Platform.IsValid() {
+		return nil, ErrInvalidPlatform
+	}
+	if s.encryptor == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+
+	var cert *Certificate
+	var secret string
+	var err error
+	switch req.Platform {
+	case PlatformApple:
+		cert, secret, err = parseAppleUpload(req)
+	case PlatformGoogle:
+		cert, secret, err = parseGoogleUpload(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if cert.ExpiresAt != nil && !cert.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: the certificate expired on %s", ErrInvalidCertificate, cert.ExpiresAt.Format("2006-01-02"))
+	}
+
+	encrypted, err := s.encryptor.EncryptString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	cert.ID = uuid.New()
+	cert.Platform = req.Platform
+	cert.Name = req.Name
+	cert.PrivateKey = encrypted
+	cert.CreatedBy = createdBy
+	cert.CreatedAt = now
+
+	if err := s.repo.CreateCertificate(ctx, cert); err != nil {
+		return nil, err
+	}
+
+	activate := req.Activate
+	if !activate {
+		active, err := s.repo.GetActiveCertificate(ctx, req.Platform)
+		if err != nil {
+			return nil, err
+		}
+		activate = active == nil
+	}
+	if activate {
+		if err := s.repo.ActivateCertificate(ctx, cert, now); err != nil {
+			return nil, err
+		}
+		cert.Active = true
+		cert.ActivatedAt = &now
+	}
+
+	return &CertificateResponse{Certificate: *cert, Expiring: cert.Expiring(now)}, nil
+}
+
+// ListCertificates lists the signing certificates, flagging those about to expire
+func (s *Service) ListCertificates(ctx context.Context) ([]CertificateResponse, error) {
+	certs, err := s.repo.ListCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	responses := make([]CertificateResponse, len(certs))
+	for i, cert := range certs {
+		responses[i] = CertificateResponse{Certificate: cert, Expiring: cert.Expiring(now)}
+	}
+	return responses, nil
+}
+
+// ActivateCertificate switches the platform to a certificate. Passes keep the pass type
+// identifier they were issued with, so switching Apple pass types only affects new passes.
+func (s *Service) ActivateCertificate(ctx context.Context, id uuid.UUID) (*CertificateResponse, error) {
+	cert, err := s.repo.GetCertificate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	now := s.now()
+	if cert.ExpiresAt != nil && !cert.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: the certificate has expired", ErrInvalidCertificate)
+	}
+	if err := s.repo.ActivateCertificate(ctx, cert, now); err != nil {
+		return nil, err
+	}
+	cert.Active = true
+	cert.ActivatedAt = &now
+
+	return &CertificateResponse{Certificate: *cert, Expiring: cert.Expiring(now)}, nil
+}
+
+// DeleteCertificate deletes a certificate that is not active
+func (s *Service) DeleteCertificate(ctx context.Context, id uuid.UUID) error {
+	cert, err := s.repo.GetCertificate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return ErrCertificateNotFound
+	}
+	if cert.Active {
+		return ErrCertificateActive
+	}
+	return s.repo.DeleteCertificate(ctx, id)
+}
+
+// appleCredentials returns the signer and APNs client certificate of the active Apple
+// certificate
+func (s *Service) appleCredentials(ctx context.Context) (*appleSigner, *tls.Certificate, error) {
+	cert, keyPEM, err := s.activeCertificate(ctx, PlatformApple)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaf, err := parseCertificatePEM(cert.CertificatePEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain, err := parseCertificatesPEM(cert.ChainPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsCert, err := tls.X509KeyPair([]byte(cert.CertificatePEM), []byte(keyPEM))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load APNs certificate: %w", err)
+	}
+
+	return &appleSigner{
+		PassTypeID: cert.Identifier,
+		TeamID:     cert.TeamID,
+		Cert:       leaf,
+		Chain:      chain,
+		Key:        key,
+	}, &tlsCert, nil
+}
+
+// googleCredentials returns the signer of the active Google service account
+func (s *Service) googleCredentials(ctx context.Context) (*googleSigner, error) {
+	cert, accountJSON, err := s.activeCertificate(ctx, PlatformGoogle)
+	if err != nil {
+		return nil, err
+	}
+
+	account, key, err := parseServiceAccount(accountJSON)
+	if err != nil {
+		return nil, err
+	}
+	tokenURI := account.TokenURI
+	if tokenURI == "" {
+		tokenURI = GoogleTokenURL
+	}
+
+	return &googleSigner{
+		IssuerID: cert.Identifier,
+		Account:  account.ClientEmail,
+		KeyID:    account.PrivateKeyID,
+		TokenURI: tokenURI,
+		Key:      key,
+	}, nil
+}
+
+func (s *Service) activeCertificate(ctx context.Context, platform Platform) (*Certificate, string, error) {
+	cert, err := s.repo.GetActiveCertificate(ctx, platform)
+	if err != nil {
+		return nil, "", err
+	}
+	if cert == nil {
+		return nil, "", ErrNoActiveCertificate
+	}
+	if s.encryptor == nil {
+		return nil, "", ErrEncryptionUnavailable
+	}
+
+	secret, err := s.encryptor.DecryptString(cert.PrivateKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	return cert, secret, nil
+}
+
+// parseAppleUpload checks that the key matches the pass type certificate and reads the
+// pass type identifier from its subject
+func parseAppleUpload(req UploadCertificateRequest) (*Certificate, string, error) {
+	if req.CertificatePEM == "" || req.PrivateKeyPEM == "" || req.ChainPEM == "" {
+		return nil, "", fmt.Errorf("%w: certificate, privateKey and chain are required", ErrInvalidCertificate)
+	}
+
+	leaf, err := parseCertificatePEM(req.CertificatePEM)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := parseCertificatesPEM(req.ChainPEM); err != nil {
+		return nil, "", err
+	}
+	key, err := parsePrivateKeyPEM(req.PrivateKeyPEM)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := tls.X509KeyPair([]byte(req.CertificatePEM), []byte(req.PrivateKeyPEM)); err != nil {
+		return nil, "", fmt.Errorf("%w: the private key does not match the certificate", ErrInvalidCertificate)
+	}
+	if _, err := signatureAlgorithm(key); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+
+	passTypeID := ""
+	for _, name := range leaf.Subject.Names {
+		if name.Type.Equal(oidUserID) {
+			passTypeID, _ = name.Value.(string)
+		}
+	}
+	if !strings.HasPrefix(passTypeID, "pass.") {
+		return nil, "", fmt.Errorf("%w: not a pass type certificate", ErrInvalidCertificate)
+	}
+
+	teamID := req.TeamID
+	if teamID == "" && len(leaf.Subject.OrganizationalUnit) > 0 {
+		teamID = leaf.Subject.OrganizationalUnit[0]
+	}
+	if teamID == "" {
+		return nil, "", fmt.Errorf("%w: teamId is required", ErrInvalidCertificate)
+	}
+
+	expiresAt := leaf.NotAfter
+	return &Certificate{
+		Identifier:     passTypeID,
+		TeamID:         teamID,
+		CertificatePEM: req.CertificatePEM,
+		ChainPEM:       req.ChainPEM,
+		Fingerprint:    security.Fingerprint(leaf.Raw),
+		ExpiresAt:      &expiresAt,
+	}, req.PrivateKeyPEM, nil
+}
+
+// parseGoogleUpload checks the service account key of a Google Wallet issuer
+func parseGoogleUpload(req UploadCertificateRequest) (*Certificate, string, error) {
+	if req.IssuerID == "" || req.ServiceAccount == "" {
+		return nil, "", fmt.Errorf("%w: issuerId and serviceAccount are required", ErrInvalidCertificate)
+	}
+
+	account, _, err := parseServiceAccount(req.ServiceAccount)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &Certificate{
+		Identifier:  req.IssuerID,
+		Account:     account.ClientEmail,
+		Fingerprint: account.PrivateKeyID,
+	}, req.ServiceAccount, nil
+}
+
+func parseServiceAccount(data string) (*googleServiceAccount, *rsa.PrivateKey, error) {
+	var account googleServiceAccount
+	if err := json.Unmarshal([]byte(data), &account); err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid service account key: %v", ErrInvalidCertificate, err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, nil, fmt.Errorf("%w: the service account key has no client_email or private_key", ErrInvalidCertificate)
+	}
+
+	key, err := parsePrivateKeyPEM(account.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: the service account key is not an RSA key", ErrInvalidCertificate)
+	}
+	return &account, rsaKey, nil
+}
+
+func parseCertificatePEM(data string) (*x509.Certificate, error) {
+	certs, err := parseCertificatesPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+func parseCertificatesPEM(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%w: no PEM certificate found", ErrInvalidCertificate)
+	}
+	return certs, nil
+}
+
+func parsePrivateKeyPEM(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM private key found", ErrInvalidCertificate)
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse private key: %v", ErrInvalidCertificate, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported private key", ErrInvalidCertificate)
+	}
+	return signer, nil
+}
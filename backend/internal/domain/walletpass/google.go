@@ -0,0 +1,356 @@
+package walletpass
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// GoogleSaveURL prefixes the signed JWT of an "Add to Google Wallet" link
+	GoogleSaveURL = "https://pay.google.com/gp/v/save/"
+	// GoogleWalletAPI is the base URL of the Google Wallet REST API
+	GoogleWalletAPI = "https://walletobjects.googleapis.com/walletobjects/v1"
+	// GoogleWalletScope is the OAuth scope the issuer service account needs
+	GoogleWalletScope = "https://www.googleapis.com/auth/wallet_object.issuer"
+	// GoogleTokenURL is used when the service account key has no token_uri
+	GoogleTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// googleServiceAccount is the JSON key of a Google service account
+type googleServiceAccount struct {
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// googleSigner signs save links and calls the Google Wallet API as the issuer
+type googleSigner struct {
+	IssuerID string
+	Account  string
+	KeyID    string
+	TokenURI string
+	Key      *rsa.PrivateKey
+}
+
+type localizedString struct {
+	DefaultValue translatedString `json:"defaultValue"`
+}
+
+type translatedString struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+func localized(value string) *localizedString {
+	return &localizedString{DefaultValue: translatedString{Language: "en-US", Value: value}}
+}
+
+type googleBarcode struct {
+	Type          string `json:"type"`
+	Value         string `json:"value"`
+	AlternateText string `json:"alternateText,omitempty"`
+}
+
+type googleTextModule struct {
+	ID     string `json:"id"`
+	Header string `json:"header"`
+	Body   string `json:"body"`
+}
+
+type googleDateTime struct {
+	Date string `json:"date"`
+}
+
+type googleTimeInterval struct {
+	Start googleDateTime `json:"start"`
+	End   googleDateTime `json:"end"`
+}
+
+type googleEventClass struct {
+	ID                 string           `json:"id"`
+	IssuerName         string           `json:"issuerName"`
+	EventName          *localizedString `json:"eventName"`
+	ReviewStatus       string           `json:"reviewStatus"`
+	HexBackgroundColor string           `json:"hexBackgroundColor,omitempty"`
+	Venue              *googleVenue     `json:"venue,omitempty"`
+	DateTime           *googleEventTime `json:"dateTime,omitempty"`
+}
+
+type googleVenue struct {
+	Name    *localizedString `json:"name"`
+	Address *localizedString `json:"address"`
+}
+
+type googleEventTime struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type googleEventObject struct {
+	ID                 string              `json:"id"`
+	ClassID            string              `json:"classId"`
+	State              string              `json:"state"`
+	TicketHolderName   string              `json:"ticketHolderName,omitempty"`
+	TicketNumber       string              `json:"ticketNumber"`
+	TicketType         *localizedString    `json:"ticketType,omitempty"`
+	HexBackgroundColor string              `json:"hexBackgroundColor,omitempty"`
+	Barcode            *googleBarcode      `json:"barcode,omitempty"`
+	ValidTimeInterval  *googleTimeInterval `json:"validTimeInterval,omitempty"`
+	TextModulesData    []googleTextModule  `json:"textModulesData,omitempty"`
+}
+
+type googleGenericClass struct {
+	ID string `json:"id"`
+}
+
+type googleGenericObject struct {
+	ID                 string              `json:"id"`
+	ClassID            string              `json:"classId"`
+	State              string              `json:"state"`
+	CardTitle          *localizedString    `json:"cardTitle"`
+	Subheader          *localizedString    `json:"subheader"`
+	Header             *localizedString    `json:"header"`
+	HexBackgroundColor string              `json:"hexBackgroundColor,omitempty"`
+	ValidTimeInterval  *googleTimeInterval `json:"validTimeInterval,omitempty"`
+	TextModulesData    []googleTextModule  `json:"textModulesData,omitempty"`
+}
+
+// googleEventClassID is shared by the tickets of a festival
+func googleEventClassID(issuerID, festivalID string) string {
+	return issuerID + ".festival-" + festivalID
+}
+
+// googleBalanceClassID is shared by the balance cards of a festival
+func googleBalanceClassID(issuerID, festivalID string) string {
+	return issuerID + ".balance-" + festivalID
+}
+
+func googleState(active bool) string {
+	if active {
+		return "ACTIVE"
+	}
+	return "INACTIVE"
+}
+
+func ticketGoogleClass(issuerID, organization string, ticket *TicketInfo) *googleEventClass {
+	return &googleEventClass{
+		ID:                 googleEventClassID(issuerID, ticket.FestivalID.String()),
+		IssuerName:         organization,
+		EventName:          localized(ticket.FestivalName),
+		ReviewStatus:       "UNDER_REVIEW",
+		HexBackgroundColor: hexColor(ticket.Color),
+		Venue: &googleVenue{
+			Name:    localized(ticket.FestivalName),
+			Address: localized(ticket.Location),
+		},
+		DateTime: &googleEventTime{
+			Start: ticket.StartDate.Format("2006-01-02"),
+			End:   ticket.EndDate.Format("2006-01-02"),
+		},
+	}
+}
+
+func ticketGoogleObject(pass *Pass, ticket *TicketInfo, barcode string, schedule []ScheduleDay) *googleEventObject {
+	object := &googleEventObject{
+		ID:                 pass.GoogleObjectID(),
+		ClassID:            googleEventClassID(pass.PassTypeID, ticket.FestivalID.String()),
+		State:              googleState(ticket.Status == "VALID"),
+		TicketHolderName:   ticket.HolderName,
+		TicketNumber:       ticket.Code,
+		TicketType:         localized(ticket.TypeName),
+		HexBackgroundColor: hexColor(ticket.Color),
+		ValidTimeInterval: &googleTimeInterval{
+			Start: googleDateTime{Date: ticket.ValidFrom.UTC().Format(time.RFC3339)},
+			End:   googleDateTime{Date: ticket.ValidUntil.UTC().Format(time.RFC3339)},
+		},
+	}
+	if barcode != "" {
+		object.Barcode = &googleBarcode{Type: "QR_CODE", Value: barcode, AlternateText: ticket.Code}
+	}
+	for _, field := range scheduleFields(schedule, ticket.Timezone) {
+		object.TextModulesData = append(object.TextModulesData, googleTextModule{
+			ID:     field.Key,
+			Header: field.Label,
+			Body:   field.Value.(string),
+		})
+	}
+	return object
+}
+
+func balanceGoogleObject(pass *Pass, wallet *WalletInfo) *googleGenericObject {
+	return &googleGenericObject{
+		ID:                 pass.GoogleObjectID(),
+		ClassID:            googleBalanceClassID(pass.PassTypeID, wallet.FestivalID.String()),
+		State:              googleState(wallet.Status != "CLOSED"),
+		CardTitle:          localized(wallet.FestivalName),
+		Subheader:          localized("Balance"),
+		Header:             localized(formatBalance(wallet)),
+		HexBackgroundColor: DefaultPassColor,
+		ValidTimeInterval: &googleTimeInterval{
+			Start: googleDateTime{Date: time.Unix(0, 0).UTC().Format(time.RFC3339)},
+			End:   googleDateTime{Date: wallet.EndDate.AddDate(0, 0, 1).UTC().Format(time.RFC3339)},
+		},
+		TextModulesData: []googleTextModule{{
+			ID:     "pay",
+			Header: "Paying",
+			Body:   "Open the festival app and show the QR code of your wallet at the bar or stand.",
+		}},
+	}
+}
+
+// saveURL signs the "Add to Google Wallet" link of a pass, which creates its class and
+// object when the user saves it
+func (g *googleSigner) saveURL(payload map[string]interface{}, origins []string, now time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"iss":     g.Account,
+		"aud":     "google",
+		"typ":     "savetowallet",
+		"iat":     now.Unix(),
+		"origins": origins,
+		"payload": payload,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = g.KeyID
+
+	signed, err := token.SignedString(g.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Google Wallet link: %w", err)
+	}
+	return GoogleSaveURL + signed, nil
+}
+
+// googleClient calls the Google Wallet API, caching the access token of the issuer
+type googleClient struct {
+	httpClient *http.Client
+	baseURL    string
+
+	mu      sync.Mutex
+	account string
+	token   string
+	expiry  time.Time
+}
+
+func newGoogleClient(httpClient *http.Client) *googleClient {
+	return &googleClient{httpClient: httpClient, baseURL: GoogleWalletAPI}
+}
+
+// patchObject updates an object the user saved. Objects not saved yet do not exist and
+// are skipped: the link creates them with the current content.
+func (c *googleClient) patchObject(ctx context.Context, signer *googleSigner, resource, id string, object interface{}) error {
+	token, err := c.accessToken(ctx, signer)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("failed to encode Google Wallet object: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s", c.baseURL, resource, url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Google Wallet request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update Google Wallet object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("Google Wallet error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func (c *googleClient) accessToken(ctx context.Context, signer *googleSigner) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token != "" && c.account == signer.Account && now.Before(c.expiry) {
+		return c.token, nil
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   signer.Account,
+		"sub":   signer.Account,
+		"aud":   signer.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": GoogleWalletScope,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = signer.KeyID
+
+	assertion, err := token.SignedString(signer.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Google token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, signer.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Google access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Google token error: %s", string(respBody))
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	c.account = signer.Account
+	c.token = tokenResp.AccessToken
+	c.expiry = now.Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second) // Expire 60s early
+	return c.token, nil
+}
+
+// hexColor normalizes a ticket color for Google Wallet
+func hexColor(color string) string {
+	if _, ok := parseHexColor(color); ok {
+		return strings.ToUpper(color)
+	}
+	return DefaultPassColor
+}
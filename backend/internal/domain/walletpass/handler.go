@@ -0,0 +1,428 @@
+package walletpass
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the routes issuing passes to users
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	me := r.Group("/me")
+	{
+		me.GET("/tickets/:id/pass", h.IssueTicketPass)
+		me.GET("/wallets/:festivalId/pass", h.IssueBalancePass)
+	}
+}
+
+// RegisterAdminRoutes registers the signing certificate management routes
+func (h *Handler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	certs := r.Group("/wallet-passes/certificates")
+	{
+		certs.GET("", h.ListCertificates)
+		certs.POST("", h.UploadCertificate)
+		certs.POST("/:id/activate", h.ActivateCertificate)
+		certs.DELETE("/:id", h.DeleteCertificate)
+	}
+}
+
+// RegisterWebhookRoutes registers the Apple Wallet web service, which devices call to
+// register for updates and fetch updated passes. Requests are authenticated with the
+// token embedded in each pass.
+func (h *Handler) RegisterWebhookRoutes(r *gin.RouterGroup) {
+	v1 := r.Group("/v1")
+	{
+		v1.POST("/devices/:device/registrations/:passType/:serial", h.RegisterDevice)
+		v1.DELETE("/devices/:device/registrations/:passType/:serial", h.UnregisterDevice)
+		v1.GET("/devices/:device/registrations/:passType", h.GetUpdatedSerials)
+		v1.GET("/passes/:passType/:serial", h.GetLatestPass)
+		v1.POST("/log", h.Log)
+	}
+}
+
+// IssueTicketPass issues the wallet pass of a ticket
+// @Summary Add ticket to wallet
+// @Description Issue the Apple Wallet or Google Wallet pass of a ticket of the user. Apple passes are returned as a .pkpass file; Google passes as an "Add to Google Wallet" link. Passes are updated when the ticket, festival dates or schedule change.
+// @Tags wallet-passes
+// @Produce application/vnd.apple.pkpass
+// @Produce json
+// @Param id path string true "Ticket ID" format(uuid)
+// @Param platform query string true "Wallet app" Enums(apple, google)
+// @Success 200 {object} response.Response{data=IssuedPass} "Google Wallet link, or the .pkpass file"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Ticket not found"
+// @Failure 503 {object} response.ErrorResponse "No signing certificate"
+// @Security BearerAuth
+// @Router /me/tickets/{id}/pass [get]
+func (h *Handler) IssueTicketPass(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid ticket ID", nil)
+		return
+	}
+
+	var req IssuePassRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	issued, err := h.service.IssueTicketPass(c.Request.Context(), userID, ticketID, req.Platform)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.writeIssued(c, issued, "ticket")
+}
+
+// IssueBalancePass issues the balance card of the wallet of the user
+// @Summary Add balance card to wallet
+// @Description Issue the Apple Wallet or Google Wallet card showing the cashless balance of the user for a festival. The card has no barcode: payments use the rotating QR code of the app. Its balance is updated after each payment or top-up.
+// @Tags wallet-passes
+// @Produce application/vnd.apple.pkpass
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param platform query string true "Wallet app" Enums(apple, google)
+// @Success 200 {object} response.Response{data=IssuedPass} "Google Wallet link, or the .pkpass file"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Wallet not found"
+// @Failure 503 {object} response.ErrorResponse "No signing certificate"
+// @Security BearerAuth
+// @Router /me/wallets/{festivalId}/pass [get]
+func (h *Handler) IssueBalancePass(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	festivalID, err := uuid.Parse(c.Param("festivalId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req IssuePassRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	issued, err := h.service.IssueBalancePass(c.Request.Context(), userID, festivalID, req.Platform)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.writeIssued(c, issued, "balance")
+}
+
+func (h *Handler) writeIssued(c *gin.Context, issued *IssuedPassFile, name string) {
+	if issued.PKPass == nil {
+		response.OK(c, issued.IssuedPass)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+name+`.pkpass"`)
+	c.Data(http.StatusOK, PKPassContentType, issued.PKPass)
+}
+
+// ListCertificates lists the pass signing certificates
+// @Summary List wallet pass certificates
+// @Description Get the Apple pass type certificates and Google Wallet issuer accounts passes are signed with. Certificates expiring within 30 days are flagged.
+// @Tags wallet-passes
+// @Produce json
+// @Success 200 {object} response.Response{data=[]CertificateResponse} "Certificates"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/wallet-passes/certificates [get]
+func (h *Handler) ListCertificates(c *gin.Context) {
+	certs, err := h.service.ListCertificates(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, certs)
+}
+
+// UploadCertificate adds a pass signing certificate
+// @Summary Upload wallet pass certificate
+// @Description Add an Apple pass type certificate with its private key and the WWDR intermediate certificate, or a Google Wallet issuer service account key. Private keys are stored encrypted. The first certificate of a platform is activated.
+// @Tags wallet-passes
+// @Accept json
+// @Produce json
+// @Param request body UploadCertificateRequest true "Certificate"
+// @Success 201 {object} response.Response{data=CertificateResponse} "Certificate added"
+// @Failure 400 {object} response.ErrorResponse "Invalid certificate"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Security BearerAuth
+// @Router /admin/wallet-passes/certificates [post]
+func (h *Handler) UploadCertificate(c *gin.Context) {
+	var req UploadCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid request body", err.Error())
+		return
+	}
+
+	cert, err := h.service.UploadCertificate(c.Request.Context(), req, actorID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, cert)
+}
+
+// ActivateCertificate makes a certificate the one passes of its platform are signed with
+// @Summary Activate wallet pass certificate
+// @Description Sign new passes of the platform with this certificate. Apple passes already issued keep their pass type identifier and are only served while a certificate for it is active.
+// @Tags wallet-passes
+// @Produce json
+// @Param id path string true "Certificate ID" format(uuid)
+// @Success 200 {object} response.Response{data=CertificateResponse} "Certificate activated"
+// @Failure 400 {object} response.ErrorResponse "Certificate expired"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Certificate not found"
+// @Security BearerAuth
+// @Router /admin/wallet-passes/certificates/{id}/activate [post]
+func (h *Handler) ActivateCertificate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid certificate ID", nil)
+		return
+	}
+
+	cert, err := h.service.ActivateCertificate(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, cert)
+}
+
+// DeleteCertificate deletes a certificate that is not active
+// @Summary Delete wallet pass certificate
+// @Description Delete a certificate and its private key. The active certificate of a platform cannot be deleted.
+// @Tags wallet-passes
+// @Param id path string true "Certificate ID" format(uuid)
+// @Success 204 "Certificate deleted"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Certificate not found"
+// @Failure 409 {object} response.ErrorResponse "Certificate is active"
+// @Security BearerAuth
+// @Router /admin/wallet-passes/certificates/{id} [delete]
+func (h *Handler) DeleteCertificate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid certificate ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteCertificate(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Apple Wallet web service. Responses follow the protocol expected by devices rather
+// than the response envelope of the API.
+
+// RegisterDevice registers a device for updates of a pass
+// @Summary Register device for pass updates
+// @Description Called by Apple Wallet when a pass is added. Authenticated with "Authorization: ApplePass <token>".
+// @Tags wallet-passes
+// @Accept json
+// @Param device path string true "Device library identifier"
+// @Param passType path string true "Pass type identifier"
+// @Param serial path string true "Serial number"
+// @Param request body RegisterDeviceRequest true "Push token"
+// @Success 201 "Registered"
+// @Success 200 "Already registered"
+// @Failure 401 "Invalid authentication token"
+// @Router /passes/v1/devices/{device}/registrations/{passType}/{serial} [post]
+func (h *Handler) RegisterDevice(c *gin.Context) {
+	var req RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.service.RegisterDevice(c.Request.Context(), c.Param("device"), c.Param("passType"), c.Param("serial"), passAuthToken(c), req.PushToken)
+	if err != nil {
+		h.handleWebServiceError(c, err)
+		return
+	}
+
+	if created {
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// UnregisterDevice stops updates of a pass to a device
+// @Summary Unregister device from pass updates
+// @Description Called by Apple Wallet when a pass is removed. Authenticated with "Authorization: ApplePass <token>".
+// @Tags wallet-passes
+// @Param device path string true "Device library identifier"
+// @Param passType path string true "Pass type identifier"
+// @Param serial path string true "Serial number"
+// @Success 200 "Unregistered"
+// @Failure 401 "Invalid authentication token"
+// @Router /passes/v1/devices/{device}/registrations/{passType}/{serial} [delete]
+func (h *Handler) UnregisterDevice(c *gin.Context) {
+	err := h.service.UnregisterDevice(c.Request.Context(), c.Param("device"), c.Param("passType"), c.Param("serial"), passAuthToken(c))
+	if err != nil {
+		h.handleWebServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetUpdatedSerials lists the passes of a device that changed
+// @Summary List updated passes of a device
+// @Description Called by Apple Wallet after a push to find the passes to fetch again
+// @Tags wallet-passes
+// @Produce json
+// @Param device path string true "Device library identifier"
+// @Param passType path string true "Pass type identifier"
+// @Param passesUpdatedSince query string false "Tag returned by the previous call"
+// @Success 200 {object} UpdatedSerials "Updated passes"
+// @Success 204 "No pass updated"
+// @Router /passes/v1/devices/{device}/registrations/{passType} [get]
+func (h *Handler) GetUpdatedSerials(c *gin.Context) {
+	updated, err := h.service.UpdatedSerials(c.Request.Context(), c.Param("device"), c.Param("passType"), c.Query("passesUpdatedSince"))
+	if err != nil {
+		h.handleWebServiceError(c, err)
+		return
+	}
+
+	if updated == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetLatestPass returns the current version of a pass
+// @Summary Get latest pass
+// @Description Called by Apple Wallet to fetch an updated pass. Authenticated with "Authorization: ApplePass <token>".
+// @Tags wallet-passes
+// @Produce application/vnd.apple.pkpass
+// @Param passType path string true "Pass type identifier"
+// @Param serial path string true "Serial number"
+// @Param If-Modified-Since header string false "Last-Modified of the version on the device"
+// @Success 200 {file} file "The .pkpass file"
+// @Success 304 "Pass not modified"
+// @Failure 401 "Invalid authentication token"
+// @Router /passes/v1/passes/{passType}/{serial} [get]
+func (h *Handler) GetLatestPass(c *gin.Context) {
+	bundle, updatedAt, err := h.service.LatestPass(c.Request.Context(), c.Param("passType"), c.Param("serial"), passAuthToken(c))
+	if err != nil {
+		h.handleWebServiceError(c, err)
+		return
+	}
+
+	lastModified := updatedAt.UTC().Truncate(time.Second)
+	if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.After(since) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	c.Data(http.StatusOK, PKPassContentType, bundle)
+}
+
+// Log records errors reported by Apple Wallet about the web service
+// @Summary Log pass errors
+// @Description Called by Apple Wallet to report problems with the passes or the web service
+// @Tags wallet-passes
+// @Accept json
+// @Success 200 "Logged"
+// @Router /passes/v1/log [post]
+func (h *Handler) Log(c *gin.Context) {
+	var req struct {
+		Logs []string `json:"logs"`
+	}
+	if err := c.ShouldBindJSON(&req); err == nil {
+		for _, entry := range req.Logs {
+			log.Warn().Str("message", entry).Msg("Apple Wallet reported a pass error")
+		}
+	}
+	c.Status(http.StatusOK)
+}
+
+func passAuthToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "ApplePass ")
+}
+
+func actorID(c *gin.Context) *uuid.UUID {
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		return &id
+	}
+	return nil
+}
+
+func (h *Handler) handleWebServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUnauthorizedDevice):
+		c.Status(http.StatusUnauthorized)
+	case errors.Is(err, ErrPassNotFound), errors.Is(err, ErrTicketNotFound), errors.Is(err, ErrWalletNotFound):
+		c.Status(http.StatusNotFound)
+	default:
+		log.Error().Err(err).Msg("Wallet pass web service error")
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidPlatform):
+		response.BadRequest(c, "INVALID_PLATFORM", err.Error(), nil)
+	case errors.Is(err, ErrTicketNotFound):
+		response.NotFound(c, "Ticket not found")
+	case errors.Is(err, ErrWalletNotFound):
+		response.NotFound(c, "Wallet not found")
+	case errors.Is(err, ErrCertificateNotFound):
+		response.NotFound(c, "Certificate not found")
+	case errors.Is(err, ErrCertificateActive):
+		response.Conflict(c, "CERTIFICATE_ACTIVE", err.Error())
+	case errors.Is(err, ErrInvalidCertificate):
+		response.BadRequest(c, "INVALID_CERTIFICATE", err.Error(), nil)
+	case errors.Is(err, ErrNoActiveCertificate), errors.Is(err, ErrEncryptionUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,168 @@
+package walletpass
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Platform is the wallet app a pass is issued for
+type Platform string
+
+const (
+	PlatformApple  Platform = "apple"
+	PlatformGoogle Platform = "google"
+)
+
+// IsValid checks if the platform is supported
+func (p Platform) IsValid() bool {
+	return p == PlatformApple || p == PlatformGoogle
+}
+
+// Kind is what a pass represents
+type Kind string
+
+const (
+	KindTicket  Kind = "ticket"
+	KindBalance Kind = "balance" // Cashless balance card of a wallet
+)
+
+// CertificateExpiryWarning is how long before expiry a signing certificate is reported
+// as expiring
+const CertificateExpiryWarning = 30 * 24 * time.Hour
+
+// Pass is a ticket or balance card issued to Apple Wallet or Google Wallet. Passes are
+// regenerated from the ticket, wallet and festival when fetched, so the record only
+// keeps what is needed to identify them and to detect changes to push.
+type Pass struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Platform   Platform   `json:"platform" gorm:"not null"`
+	Kind       Kind       `json:"kind" gorm:"not null"`
+	FestivalID uuid.UUID  `json:"festivalId" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID  `json:"userId" gorm:"type:uuid;not null;index"`
+	TicketID   *uuid.UUID `json:"ticketId,omitempty" gorm:"type:uuid"`
+	WalletID   *uuid.UUID `json:"walletId,omitempty" gorm:"type:uuid"`
+	PassTypeID string     `json:"passTypeId"` // Apple pass type identifier or Google issuer ID at issue time
+	AuthToken  string     `json:"-"`          // Sent back by Apple devices to the web service
+	Balance    int64      `json:"balance"`    // Balance shown on a balance card, in cents
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"` // Last change pushed to the devices
+}
+
+func (Pass) TableName() string {
+	return "wallet_passes"
+}
+
+// SerialNumber identifies the pass on Apple devices
+func (p *Pass) SerialNumber() string {
+	return p.ID.String()
+}
+
+// GoogleObjectID identifies the pass object in Google Wallet
+func (p *Pass) GoogleObjectID() string {
+	return p.PassTypeID + "." + p.ID.String()
+}
+
+// Registration is an Apple device registered for updates of a pass
+type Registration struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PassID          uuid.UUID `json:"passId" gorm:"type:uuid;not null;index"`
+	DeviceLibraryID string    `json:"deviceLibraryId" gorm:"not null"`
+	PushToken       string    `json:"-" gorm:"not null"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func (Registration) TableName() string {
+	return "wallet_pass_registrations"
+}
+
+// Certificate holds the credentials passes are signed with: the pass type certificate
+// and key for Apple, the service account of the issuer for Google. Private keys are
+// stored encrypted. One certificate per platform is active.
+type Certificate struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Platform       Platform   `json:"platform" gorm:"not null"`
+	Name           string     `json:"name" gorm:"not null"`
+	Identifier     string     `json:"identifier" gorm:"not null"` // Pass type identifier or Google issuer ID
+	TeamID         string     `json:"teamId,omitempty"`           // Apple team identifier
+	Account        string     `json:"account,omitempty"`          // Google service account email
+	CertificatePEM string     `json:"-"`                          // Apple pass type certificate
+	ChainPEM       string     `json:"-"`                          // Apple WWDR intermediate certificate
+	PrivateKey     string     `json:"-"`                          // Encrypted PEM private key
+	Fingerprint    string     `json:"fingerprint"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Active         bool       `json:"active"`
+	CreatedBy      *uuid.UUID `json:"createdBy,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ActivatedAt    *time.Time `json:"activatedAt,omitempty"`
+}
+
+func (Certificate) TableName() string {
+	return "wallet_pass_certificates"
+}
+
+// Expiring reports whether the certificate expires within CertificateExpiryWarning
+func (c *Certificate) Expiring(now time.Time) bool {
+	return c.ExpiresAt != nil && c.ExpiresAt.Before(now.Add(CertificateExpiryWarning))
+}
+
+// Request/Response types
+
+// IssuePassRequest selects the wallet app to issue a pass for
+type IssuePassRequest struct {
+	Platform Platform `form:"platform" binding:"required"`
+}
+
+// IssuedPass is returned for Google Wallet passes, which are added through a link;
+// Apple Wallet passes are returned as a .pkpass file
+type IssuedPass struct {
+	PassID  uuid.UUID `json:"passId"`
+	SaveURL string    `json:"saveUrl"`
+}
+
+// UploadCertificateRequest adds signing credentials. Apple needs the pass type
+// certificate, its private key and the WWDR intermediate certificate; Google needs the
+// issuer ID and the JSON key of the service account.
+type UploadCertificateRequest struct {
+	Platform       Platform `json:"platform" binding:"required"`
+	Name           string   `json:"name" binding:"required,max=100"`
+	CertificatePEM string   `json:"certificate,omitempty"`
+	ChainPEM       string   `json:"chain,omitempty"`
+	PrivateKeyPEM  string   `json:"privateKey,omitempty"`
+	TeamID         string   `json:"teamId,omitempty"`
+	IssuerID       string   `json:"issuerId,omitempty"`
+	ServiceAccount string   `json:"serviceAccount,omitempty"`
+	Activate       bool     `json:"activate"`
+}
+
+// CertificateResponse describes a certificate without its secrets
+type CertificateResponse struct {
+	Certificate
+	Expiring bool `json:"expiring"`
+}
+
+// UpdatedSerials is returned to Apple devices asking which passes changed
+type UpdatedSerials struct {
+	LastUpdated   string   `json:"lastUpdated"`
+	SerialNumbers []string `json:"serialNumbers"`
+}
+
+// RegisterDeviceRequest is sent by Apple devices registering for pass updates
+type RegisterDeviceRequest struct {
+	PushToken string `json:"pushToken" binding:"required"`
+}
+
+// Errors
+var (
+	ErrPassNotFound          = errors.New("pass not found")
+	ErrTicketNotFound        = errors.New("ticket not found")
+	ErrWalletNotFound        = errors.New("wallet not found")
+	ErrInvalidPlatform       = errors.New("platform must be apple or google")
+	ErrNoActiveCertificate   = errors.New("no active signing certificate for the platform")
+	ErrCertificateNotFound   = errors.New("certificate not found")
+	ErrCertificateActive     = errors.New("the active certificate cannot be deleted")
+	ErrInvalidCertificate    = errors.New("invalid certificate")
+	ErrEncryptionUnavailable = errors.New("wallet pass key encryption is not configured")
+	ErrUnauthorizedDevice    = errors.New("invalid pass authentication token")
+)
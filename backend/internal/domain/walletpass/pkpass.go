@@ -0,0 +1,292 @@
+package walletpass
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PKPassContentType is the media type of Apple Wallet pass bundles
+const PKPassContentType = "application/vnd.apple.pkpass"
+
+// DefaultPassColor is used when the ticket type has no color
+const DefaultPassColor = "#1F2937"
+
+// applePass is the pass.json of a .pkpass bundle
+type applePass struct {
+	FormatVersion       int                 `json:"formatVersion"`
+	PassTypeIdentifier  string              `json:"passTypeIdentifier"`
+	SerialNumber        string              `json:"serialNumber"`
+	TeamIdentifier      string              `json:"teamIdentifier"`
+	OrganizationName    string              `json:"organizationName"`
+	Description         string              `json:"description"`
+	WebServiceURL       string              `json:"webServiceURL,omitempty"`
+	AuthenticationToken string              `json:"authenticationToken,omitempty"`
+	BackgroundColor     string              `json:"backgroundColor,omitempty"`
+	ForegroundColor     string              `json:"foregroundColor,omitempty"`
+	LabelColor          string              `json:"labelColor,omitempty"`
+	RelevantDate        string              `json:"relevantDate,omitempty"`
+	ExpirationDate      string              `json:"expirationDate,omitempty"`
+	Voided              bool                `json:"voided,omitempty"`
+	Barcodes            []appleBarcode      `json:"barcodes,omitempty"`
+	EventTicket         *applePassStructure `json:"eventTicket,omitempty"`
+	StoreCard           *applePassStructure `json:"storeCard,omitempty"`
+}
+
+type appleBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+type applePassStructure struct {
+	HeaderFields    []appleField `json:"headerFields,omitempty"`
+	PrimaryFields   []appleField `json:"primaryFields,omitempty"`
+	SecondaryFields []appleField `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []appleField `json:"auxiliaryFields,omitempty"`
+	BackFields      []appleField `json:"backFields,omitempty"`
+}
+
+type appleField struct {
+	Key           string      `json:"key"`
+	Label         string      `json:"label,omitempty"`
+	Value         interface{} `json:"value"`
+	ChangeMessage string      `json:"changeMessage,omitempty"` // Shown in a notification when the value changes
+	DateStyle     string      `json:"dateStyle,omitempty"`
+	TimeStyle     string      `json:"timeStyle,omitempty"`
+}
+
+// appleSigner signs pass bundles with a pass type certificate
+type appleSigner struct {
+	PassTypeID string
+	TeamID     string
+	Cert       *x509.Certificate
+	Chain      []*x509.Certificate
+	Key        crypto.Signer
+}
+
+// ticketApplePass lays out a ticket as an event ticket showing the ticket QR code
+func ticketApplePass(ticket *TicketInfo, barcode string, schedule []ScheduleDay) *applePass {
+	p := &applePass{
+		Description:     ticket.FestivalName + " ticket",
+		BackgroundColor: rgbColor(ticket.Color),
+		ForegroundColor: "rgb(255, 255, 255)",
+		LabelColor:      "rgb(209, 213, 219)",
+		RelevantDate:    ticket.ValidFrom.Format(time.RFC3339),
+		ExpirationDate:  ticket.ValidUntil.Format(time.RFC3339),
+		Voided:          ticket.Status != "VALID",
+		EventTicket: &applePassStructure{
+			HeaderFields: []appleField{
+				{Key: "type", Label: "TICKET", Value: ticket.TypeName},
+			},
+			PrimaryFields: []appleField{
+				{Key: "festival", Label: "FESTIVAL", Value: ticket.FestivalName},
+			},
+			SecondaryFields: []appleField{
+				{Key: "holder", Label: "HOLDER", Value: ticket.HolderName},
+				{Key: "dates", Label: "DATES", Value: formatDateRange(ticket.StartDate, ticket.EndDate), ChangeMessage: "The festival dates changed to %@"},
+			},
+			AuxiliaryFields: []appleField{
+				{Key: "location", Label: "LOCATION", Value: ticket.Location},
+				{Key: "status", Label: "STATUS", Value: ticket.Status, ChangeMessage: "Your ticket is now %@"},
+			},
+			BackFields: append(scheduleFields(schedule, ticket.Timezone),
+				appleField{Key: "code", Label: "Ticket code", Value: ticket.Code},
+			),
+		},
+	}
+	if barcode != "" {
+		p.Barcodes = []appleBarcode{{
+			Format:          "PKBarcodeFormatQR",
+			Message:         barcode,
+			MessageEncoding: "iso-8859-1",
+			AltText:         ticket.Code,
+		}}
+	}
+	return p
+}
+
+// balanceApplePass lays out a wallet as a store card showing its balance. It has no
+// barcode: payments use the rotating QR code of the app, which a static pass cannot show.
+func balanceApplePass(wallet *WalletInfo) *applePass {
+	return &applePass{
+		Description:     wallet.FestivalName + " cashless balance",
+		BackgroundColor: rgbColor(DefaultPassColor),
+		ForegroundColor: "rgb(255, 255, 255)",
+		LabelColor:      "rgb(209, 213, 219)",
+		ExpirationDate:  wallet.EndDate.AddDate(0, 0, 1).Format(time.RFC3339),
+		Voided:          wallet.Status == "CLOSED",
+		StoreCard: &applePassStructure{
+			HeaderFields: []appleField{
+				{Key: "festival", Label: "FESTIVAL", Value: wallet.FestivalName},
+			},
+			PrimaryFields: []appleField{
+				{Key: "balance", Label: "BALANCE", Value: formatBalance(wallet), ChangeMessage: "Your balance is now %@"},
+			},
+			SecondaryFields: []appleField{
+				{Key: "status", Label: "STATUS", Value: wallet.Status, ChangeMessage: "Your wallet is now %@"},
+			},
+			BackFields: []appleField{
+				{Key: "pay", Label: "Paying", Value: "Open the festival app and show the QR code of your wallet at the bar or stand."},
+			},
+		},
+	}
+}
+
+// buildPKPass zips pass.json with its icons, the manifest of their SHA-1 hashes and
+// the signature of the manifest
+func buildPKPass(p *applePass, signer *appleSigner, signedAt time.Time) ([]byte, error) {
+	p.FormatVersion = 1
+	p.PassTypeIdentifier = signer.PassTypeID
+	p.TeamIdentifier = signer.TeamID
+
+	passJSON, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode pass: %w", err)
+	}
+
+	files := map[string][]byte{"pass.json": passJSON}
+	for name, size := range map[string]int{"icon.png": 29, "icon@2x.png": 58, "icon@3x.png": 87} {
+		icon, err := squareIcon(size, p.BackgroundColor)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = icon
+	}
+
+	manifest := make(map[string]string, len(files))
+	for name, data := range files {
+		sum := sha1.Sum(data)
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	files["manifest.json"] = manifestJSON
+
+	signature, err := signDetached(manifestJSON, signer.Cert, signer.Chain, signer.Key, signedAt)
+	if err != nil {
+		return nil, err
+	}
+	files["signature"] = signature
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write pass bundle: %w", err)
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, fmt.Errorf("failed to write pass bundle: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write pass bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// scheduleFields lists the opening hours of each festival day on the back of a ticket
+func scheduleFields(schedule []ScheduleDay, timezone string) []appleField {
+	loc := loadLocation(timezone)
+	fields := make([]appleField, 0, len(schedule))
+	for _, day := range schedule {
+		date, err := time.Parse("2006-01-02", day.Day)
+		if err != nil {
+			continue
+		}
+		fields = append(fields, appleField{
+			Key:           "day-" + day.Day,
+			Label:         date.Format("Monday 2 January"),
+			Value:         day.Start.In(loc).Format("15:04") + " - " + day.End.In(loc).Format("15:04"),
+			ChangeMessage: "Schedule changed: " + date.Format("Monday") + " %@",
+		})
+	}
+	return fields
+}
+
+func formatDateRange(start, end time.Time) string {
+	if start.Equal(end) {
+		return start.Format("2 Jan 2006")
+	}
+	if start.Year() == end.Year() && start.Month() == end.Month() {
+		return fmt.Sprintf("%d - %s", start.Day(), end.Format("2 Jan 2006"))
+	}
+	return start.Format("2 Jan") + " - " + end.Format("2 Jan 2006")
+}
+
+// formatBalance shows a balance in the festival currency, whose exchange rate is the
+// value of one unit in euros
+func formatBalance(wallet *WalletInfo) string {
+	if wallet.ExchangeRate <= 0 || wallet.CurrencyName == "" {
+		return fmt.Sprintf("%.2f EUR", float64(wallet.Balance)/100)
+	}
+	units := float64(wallet.Balance) / 100 / wallet.ExchangeRate
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", units), "0"), ".") + " " + wallet.CurrencyName
+}
+
+func loadLocation(timezone string) *time.Location {
+	if loc, err := time.LoadLocation(timezone); err == nil && timezone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// rgbColor converts a #RRGGBB color to the rgb() notation of pass.json
+func rgbColor(hexColor string) string {
+	c, ok := parseHexColor(hexColor)
+	if !ok {
+		c, _ = parseHexColor(DefaultPassColor)
+	}
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.R, c.G, c.B)
+}
+
+func parseHexColor(s string) (color.RGBA, bool) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "#"))
+	if err != nil || len(b) != 3 {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: b[0], G: b[1], B: b[2], A: 255}, true
+}
+
+// squareIcon draws the plain icon Apple Wallet requires in every bundle
+func squareIcon(size int, rgb string) ([]byte, error) {
+	var c color.RGBA
+	if _, err := fmt.Sscanf(rgb, "rgb(%d, %d, %d)", &c.R, &c.G, &c.B); err != nil {
+		c, _ = parseHexColor(DefaultPassColor)
+	}
+	c.A = 255
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to draw pass icon: %w", err)
+	}
+	return buf.Bytes(), nil
+}
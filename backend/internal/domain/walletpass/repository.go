@@ -0,0 +1,363 @@
+package walletpass
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketInfo is what a ticket pass shows
+type TicketInfo struct {
+	ID           uuid.UUID
+	UserID       *uuid.UUID
+	FestivalID   uuid.UUID
+	Code         string
+	HolderName   string
+	Status       string
+	TypeName     string
+	ValidFrom    time.Time
+	ValidUntil   time.Time
+	Color        string
+	FestivalName string
+	Location     string
+	Timezone     string
+	StartDate    time.Time
+	EndDate      time.Time
+}
+
+// WalletInfo is what a balance card shows
+type WalletInfo struct {
+	ID           uuid.UUID
+	UserID       *uuid.UUID
+	FestivalID   uuid.UUID
+	Balance      int64
+	Status       string
+	FestivalName string
+	CurrencyName string
+	ExchangeRate float64
+	Timezone     string
+	EndDate      time.Time
+}
+
+// ScheduleDay is the opening time of the stages on a festival day
+type ScheduleDay struct {
+	Day   string
+	Start time.Time
+	End   time.Time
+}
+
+type Repository interface {
+	// Passes
+	CreatePass(ctx context.Context, pass *Pass) error
+	GetPassByID(ctx context.Context, id uuid.UUID) (*Pass, error)
+	GetTicketPass(ctx context.Context, ticketID uuid.UUID, platform Platform) (*Pass, error)
+	GetBalancePass(ctx context.Context, walletID uuid.UUID, platform Platform) (*Pass, error)
+	UpdatePass(ctx context.Context, pass *Pass) error
+	FindStalePasses(ctx context.Context, limit int) ([]Pass, error)
+
+	// Apple device registrations
+	CreateRegistration(ctx context.Context, registration *Registration) (bool, error)
+	DeleteRegistration(ctx context.Context, deviceLibraryID string, passID uuid.UUID) error
+	DeleteRegistrationsByPushToken(ctx context.Context, pushToken string) error
+	GetRegistrations(ctx context.Context, passID uuid.UUID) ([]Registration, error)
+	GetUpdatedSerials(ctx context.Context, deviceLibraryID, passTypeID string, since time.Time) ([]Pass, error)
+
+	// Content
+	GetTicketInfo(ctx context.Context, ticketID uuid.UUID) (*TicketInfo, error)
+	GetWalletInfo(ctx context.Context, walletID uuid.UUID) (*WalletInfo, error)
+	GetUserWalletID(ctx context.Context, userID, festivalID uuid.UUID) (uuid.UUID, error)
+	GetSchedule(ctx context.Context, festivalID uuid.UUID) ([]ScheduleDay, error)
+
+	// Certificates
+	CreateCertificate(ctx context.Context, cert *Certificate) error
+	GetCertificate(ctx context.Context, id uuid.UUID) (*Certificate, error)
+	GetActiveCertificate(ctx context.Context, platform Platform) (*Certificate, error)
+	ListCertificates(ctx context.Context) ([]Certificate, error)
+	ActivateCertificate(ctx context.Context, cert *Certificate, at time.Time) error
+	DeleteCertificate(ctx context.Context, id uuid.UUID) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreatePass(ctx context.Context, pass *Pass) error {
+	if err := r.db.WithContext(ctx).Create(pass).Error; err != nil {
+		return fmt.Errorf("failed to create pass: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetPassByID(ctx context.Context, id uuid.UUID) (*Pass, error) {
+	var pass Pass
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&pass).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+	return &pass, nil
+}
+
+func (r *repository) GetTicketPass(ctx context.Context, ticketID uuid.UUID, platform Platform) (*Pass, error) {
+	var pass Pass
+	err := r.db.WithContext(ctx).
+		Where("ticket_id = ? AND platform = ?", ticketID, platform).
+		First(&pass).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ticket pass: %w", err)
+	}
+	return &pass, nil
+}
+
+func (r *repository) GetBalancePass(ctx context.Context, walletID uuid.UUID, platform Platform) (*Pass, error) {
+	var pass Pass
+	err := r.db.WithContext(ctx).
+		Where("wallet_id = ? AND platform = ?", walletID, platform).
+		First(&pass).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get balance pass: %w", err)
+	}
+	return &pass, nil
+}
+
+func (r *repository) UpdatePass(ctx context.Context, pass *Pass) error {
+	err := r.db.WithContext(ctx).Model(&Pass{}).
+		Where("id = ?", pass.ID).
+		Updates(map[string]interface{}{
+			"balance":    pass.Balance,
+			"updated_at": pass.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update pass: %w", err)
+	}
+	return nil
+}
+
+// FindStalePasses returns the passes whose content changed since they were last
+// pushed: the balance of a balance card, or for a ticket the ticket itself, the
+// festival or its schedule of performances
+func (r *repository) FindStalePasses(ctx context.Context, limit int) ([]Pass, error) {
+	var passes []Pass
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT p.* FROM wallet_passes p
+		WHERE (p.kind = 'balance' AND EXISTS (
+				SELECT 1 FROM wallets w WHERE w.id = p.wallet_id AND w.balance <> p.balance))
+			OR (p.kind = 'ticket' AND (
+				EXISTS (SELECT 1 FROM tickets t WHERE t.id = p.ticket_id AND t.updated_at > p.updated_at)
+				OR EXISTS (SELECT 1 FROM festivals f WHERE f.id = p.festival_id AND f.updated_at > p.updated_at)
+				OR EXISTS (SELECT 1 FROM performances pf WHERE pf.festival_id = p.festival_id AND pf.updated_at > p.updated_at)))
+		ORDER BY p.updated_at
+		LIMIT ?`, limit).
+		Scan(&passes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale passes: %w", err)
+	}
+	return passes, nil
+}
+
+// CreateRegistration registers a device for updates of a pass, reporting false when it
+// was already registered
+func (r *repository) CreateRegistration(ctx context.Context, registration *Registration) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		INSERT INTO wallet_pass_registrations (id, pass_id, device_library_id, push_token, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (device_library_id, pass_id) DO UPDATE SET push_token = EXCLUDED.push_token`,
+		registration.ID, registration.PassID, registration.DeviceLibraryID, registration.PushToken, registration.CreatedAt)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to register device: %w", result.Error)
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Registration{}).Where("id = ?", registration.ID).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to register device: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *repository) DeleteRegistration(ctx context.Context, deviceLibraryID string, passID uuid.UUID) error {
+	err := r.db.WithContext(ctx).
+		Where("device_library_id = ? AND pass_id = ?", deviceLibraryID, passID).
+		Delete(&Registration{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to unregister device: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteRegistrationsByPushToken(ctx context.Context, pushToken string) error {
+	if err := r.db.WithContext(ctx).Where("push_token = ?", pushToken).Delete(&Registration{}).Error; err != nil {
+		return fmt.Errorf("failed to delete registrations: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetRegistrations(ctx context.Context, passID uuid.UUID) ([]Registration, error) {
+	var registrations []Registration
+	if err := r.db.WithContext(ctx).Where("pass_id = ?", passID).Find(&registrations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get registrations: %w", err)
+	}
+	return registrations, nil
+}
+
+// GetUpdatedSerials returns the passes of a pass type registered on a device and
+// updated after since
+func (r *repository) GetUpdatedSerials(ctx context.Context, deviceLibraryID, passTypeID string, since time.Time) ([]Pass, error) {
+	var passes []Pass
+	err := r.db.WithContext(ctx).
+		Joins("JOIN wallet_pass_registrations r ON r.pass_id = wallet_passes.id").
+		Where("r.device_library_id = ? AND wallet_passes.pass_type_id = ? AND wallet_passes.updated_at > ?", deviceLibraryID, passTypeID, since).
+		Find(&passes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated passes: %w", err)
+	}
+	return passes, nil
+}
+
+func (r *repository) GetTicketInfo(ctx context.Context, ticketID uuid.UUID) (*TicketInfo, error) {
+	var info TicketInfo
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT t.id, t.user_id, t.festival_id, t.code, t.holder_name, t.status,
+			tt.name AS type_name, tt.valid_from, tt.valid_until, COALESCE(tt.settings->>'color', '') AS color,
+			f.name AS festival_name, f.location, f.timezone, f.start_date, f.end_date
+		FROM tickets t
+		JOIN ticket_types tt ON tt.id = t.ticket_type_id
+		JOIN festivals f ON f.id = t.festival_id
+		WHERE t.id = ?`, ticketID).
+		Scan(&info).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if info.ID == uuid.Nil {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func (r *repository) GetWalletInfo(ctx context.Context, walletID uuid.UUID) (*WalletInfo, error) {
+	var info WalletInfo
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT w.id, w.user_id, w.festival_id, w.balance, w.status,
+			f.name AS festival_name, f.currency_name, f.exchange_rate, f.timezone, f.end_date
+		FROM wallets w
+		JOIN festivals f ON f.id = w.festival_id
+		WHERE w.id = ?`, walletID).
+		Scan(&info).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if info.ID == uuid.Nil {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+// GetUserWalletID returns the wallet of a user for a festival, or uuid.Nil
+func (r *repository) GetUserWalletID(ctx context.Context, userID, festivalID uuid.UUID) (uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("wallets").
+		Where("user_id = ? AND festival_id = ? AND merged_into_id IS NULL", userID, festivalID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if len(ids) == 0 {
+		return uuid.Nil, nil
+	}
+	return ids[0], nil
+}
+
+// GetSchedule returns the first start and last end of the performances of each day
+func (r *repository) GetSchedule(ctx context.Context, festivalID uuid.UUID) ([]ScheduleDay, error) {
+	var days []ScheduleDay
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT day, MIN(start_time) AS start, MAX(end_time) AS "end"
+		FROM performances
+		WHERE festival_id = ? AND status <> 'CANCELLED'
+		GROUP BY day
+		ORDER BY day`, festivalID).
+		Scan(&days).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return days, nil
+}
+
+func (r *repository) CreateCertificate(ctx context.Context, cert *Certificate) error {
+	if err := r.db.WithContext(ctx).Create(cert).Error; err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetCertificate(ctx context.Context, id uuid.UUID) (*Certificate, error) {
+	var cert Certificate
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&cert).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+func (r *repository) GetActiveCertificate(ctx context.Context, platform Platform) (*Certificate, error) {
+	var cert Certificate
+	err := r.db.WithContext(ctx).Where("platform = ? AND active", platform).First(&cert).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get active certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+func (r *repository) ListCertificates(ctx context.Context) ([]Certificate, error) {
+	var certs []Certificate
+	if err := r.db.WithContext(ctx).Order("platform, created_at DESC").Find(&certs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list certificates: %w", err)
+	}
+	return certs, nil
+}
+
+// ActivateCertificate makes the certificate the only active one of its platform
+func (r *repository) ActivateCertificate(ctx context.Context, cert *Certificate, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Certificate{}).
+			Where("platform = ? AND active AND id <> ?", cert.Platform, cert.ID).
+			Update("active", false).Error
+		if err != nil {
+			return fmt.Errorf("failed to deactivate certificates: %w", err)
+		}
+		err = tx.Model(&Certificate{}).
+			Where("id = ?", cert.ID).
+			Updates(map[string]interface{}{"active": true, "activated_at": at}).Error
+		if err != nil {
+			return fmt.Errorf("failed to activate certificate: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repository) DeleteCertificate(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&Certificate{}).Error; err != nil {
+		return fmt.Errorf("failed to delete certificate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package walletpass
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) CreatePass(ctx context.Context, pass *Pass) error {
+	args := m.Called(ctx, pass)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPassByID(ctx context.Context, id uuid.UUID) (*Pass, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pass), args.Error(1)
+}
+
+func (m *MockRepository) GetTicketPass(ctx context.Context, ticketID uuid.UUID, platform Platform) (*Pass, error) {
+	args := m.Called(ctx, ticketID, platform)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pass), args.Error(1)
+}
+
+func (m *MockRepository) GetBalancePass(ctx context.Context, walletID uuid.UUID, platform Platform) (*Pass, error) {
+	args := m.Called(ctx, walletID, platform)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Pass), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePass(ctx context.Context, pass *Pass) error {
+	args := m.Called(ctx, pass)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindStalePasses(ctx context.Context, limit int) ([]Pass, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]Pass), args.Error(1)
+}
+
+func (m *MockRepository) CreateRegistration(ctx context.Context, registration *Registration) (bool, error) {
+	args := m.Called(ctx, registration)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) DeleteRegistration(ctx context.Context, deviceLibraryID string, passID uuid.UUID) error {
+	args := m.Called(ctx, deviceLibraryID, passID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteRegistrationsByPushToken(ctx context.Context, pushToken string) error {
+	args := m.Called(ctx, pushToken)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRegistrations(ctx context.Context, passID uuid.UUID) ([]Registration, error) {
+	args := m.Called(ctx, passID)
+	return args.Get(0).([]Registration), args.Error(1)
+}
+
+func (m *MockRepository) GetUpdatedSerials(ctx context.Context, deviceLibraryID, passTypeID string, since time.Time) ([]Pass, error) {
+	args := m.Called(ctx, deviceLibraryID, passTypeID, since)
+	return args.Get(0).([]Pass), args.Error(1)
+}
+
+func (m *MockRepository) GetTicketInfo(ctx context.Context, ticketID uuid.UUID) (*TicketInfo, error) {
+	args := m.Called(ctx, ticketID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TicketInfo), args.Error(1)
+}
+
+func (m *MockRepository) GetWalletInfo(ctx context.Context, walletID uuid.UUID) (*WalletInfo, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WalletInfo), args.Error(1)
+}
+
+func (m *MockRepository) GetUserWalletID(ctx context.Context, userID, festivalID uuid.UUID) (uuid.UUID, error) {
+	args := m.Called(ctx, userID, festivalID)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetSchedule(ctx context.Context, festivalID uuid.UUID) ([]ScheduleDay, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]ScheduleDay), args.Error(1)
+}
+
+func (m *MockRepository) CreateCertificate(ctx context.Context, cert *Certificate) error {
+	args := m.Called(ctx, cert)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCertificate(ctx context.Context, id uuid.UUID) (*Certificate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Certificate), args.Error(1)
+}
+
+func (m *MockRepository) GetActiveCertificate(ctx context.Context, platform Platform) (*Certificate, error) {
+	args := m.Called(ctx, platform)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Certificate), args.Error(1)
+}
+
+func (m *MockRepository) ListCertificates(ctx context.Context) ([]Certificate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Certificate), args.Error(1)
+}
+
+func (m *MockRepository) ActivateCertificate(ctx context.Context, cert *Certificate, at time.Time) error {
+	args := m.Called(ctx, cert, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteCertificate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,580 @@
+package walletpass
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+	"github.com/rs/zerolog/log"
+)
+
+// Task type for the pass update job
+const TypeRefreshPasses = "walletpass:refresh"
+
+// RefreshBatchSize is the number of changed passes pushed per run of the update job
+const RefreshBatchSize = 500
+
+// KeyEncryptor encrypts the private keys of the signing certificates, satisfied by
+// *security.Encryptor
+type KeyEncryptor interface {
+	EncryptString(plaintext string) (string, error)
+	DecryptString(ciphertext string) (string, error)
+}
+
+// TicketBarcoder signs the QR code shown on ticket passes, satisfied by
+// *qrcode.Generator
+type TicketBarcoder interface {
+	GenerateQRDataOnly(ticketID, userID, festivalID uuid.UUID, ticketCode string, validUntil time.Time) (string, error)
+}
+
+// NewKeyEncryptor creates the encryptor of the certificate private keys from a base64
+// 32-byte key. It returns nil when no key is configured, in which case certificates
+// cannot be uploaded or used.
+func NewKeyEncryptor(encodedKey string) (KeyEncryptor, error) {
+	if encodedKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet pass encryption key: %w", err)
+	}
+	encryptor, err := security.NewEncryptor(security.EncryptionConfig{PrimaryKey: key})
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet pass encryption key: %w", err)
+	}
+	return encryptor, nil
+}
+
+// Config configures pass issuance
+type Config struct {
+	// WebServiceURL is the public URL of the Apple Wallet web service, ending in
+	// /api/v1/passes. Apple passes are not updated when it is empty.
+	WebServiceURL string
+	// OrganizationName is shown on the passes
+	OrganizationName string
+	// Origins are the web origins allowed to show "Add to Google Wallet" buttons
+	Origins []string
+}
+
+type Service struct {
+	repo      Repository
+	encryptor KeyEncryptor
+	barcodes  TicketBarcoder
+	config    Config
+	apns      *apnsClient
+	google    *googleClient
+	now       func() time.Time
+}
+
+func NewService(repo Repository, encryptor KeyEncryptor, barcodes TicketBarcoder, config Config) *Service {
+	if config.OrganizationName == "" {
+		config.OrganizationName = "Festivals"
+	}
+	return &Service{
+		repo:      repo,
+		encryptor: encryptor,
+		barcodes:  barcodes,
+		config:    config,
+		apns:      newAPNsClient(),
+		google:    newGoogleClient(&http.Client{Timeout: 30 * time.Second}),
+		now:       time.Now,
+	}
+}
+
+// IssuedPassFile is an issued pass: a .pkpass bundle for Apple Wallet, a save link for
+// Google Wallet
+type IssuedPassFile struct {
+	IssuedPass
+	PKPass []byte
+}
+
+// IssueTicketPass issues the pass of a ticket owned by the user. Issuing it again
+// returns the same pass with its current content.
+func (s *Service) IssueTicketPass(ctx context.Context, userID, ticketID uuid.UUID, platform Platform) (*IssuedPassFile, error) {
+	if !platform.IsValid() {
+		return nil, ErrInvalidPlatform
+	}
+
+	ticket, err := s.repo.GetTicketInfo(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == nil || ticket.UserID == nil || *ticket.UserID != userID {
+		return nil, ErrTicketNotFound
+	}
+
+	pass, err := s.repo.GetTicketPass(ctx, ticketID, platform)
+	if err != nil {
+		return nil, err
+	}
+	if pass == nil {
+		pass = &Pass{
+			Kind:       KindTicket,
+			FestivalID: ticket.FestivalID,
+			UserID:     userID,
+			TicketID:   &ticket.ID,
+		}
+	}
+
+	return s.issue(ctx, pass, platform)
+}
+
+// IssueBalancePass issues the balance card of the wallet of the user for a festival
+func (s *Service) IssueBalancePass(ctx context.Context, userID, festivalID uuid.UUID, platform Platform) (*IssuedPassFile, error) {
+	if !platform.IsValid() {
+		return nil, ErrInvalidPlatform
+	}
+
+	walletID, err := s.repo.GetUserWalletID(ctx, userID, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if walletID == uuid.Nil {
+		return nil, ErrWalletNotFound
+	}
+
+	pass, err := s.repo.GetBalancePass(ctx, walletID, platform)
+	if err != nil {
+		return nil, err
+	}
+	if pass == nil {
+		pass = &Pass{
+			Kind:       KindBalance,
+			FestivalID: festivalID,
+			UserID:     userID,
+			WalletID:   &walletID,
+		}
+	}
+
+	return s.issue(ctx, pass, platform)
+}
+
+// issue renders a pass, creating its record on first issue with the identifier of the
+// active certificate
+func (s *Service) issue(ctx context.Context, pass *Pass, platform Platform) (*IssuedPassFile, error) {
+	switch platform {
+	case PlatformApple:
+		signer, _, err := s.appleCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.createPass(ctx, pass, platform, signer.PassTypeID); err != nil {
+			return nil, err
+		}
+		if pass.PassTypeID != signer.PassTypeID {
+			// Issued with a pass type the active certificate cannot sign for
+			return nil, ErrNoActiveCertificate
+		}
+		bundle, err := s.renderApplePass(ctx, pass, signer)
+		if err != nil {
+			return nil, err
+		}
+		return &IssuedPassFile{IssuedPass: IssuedPass{PassID: pass.ID}, PKPass: bundle}, nil
+
+	default:
+		signer, err := s.googleCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.createPass(ctx, pass, platform, signer.IssuerID); err != nil {
+			return nil, err
+		}
+		payload, err := s.googlePayload(ctx, pass)
+		if err != nil {
+			return nil, err
+		}
+		link, err := signer.saveURL(payload, s.config.Origins, s.now())
+		if err != nil {
+			return nil, err
+		}
+		return &IssuedPassFile{IssuedPass: IssuedPass{PassID: pass.ID, SaveURL: link}}, nil
+	}
+}
+
+func (s *Service) createPass(ctx context.Context, pass *Pass, platform Platform, passTypeID string) error {
+	if pass.ID != uuid.Nil {
+		return nil
+	}
+
+	token, err := generateAuthToken()
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	pass.ID = uuid.New()
+	pass.Platform = platform
+	pass.PassTypeID = passTypeID
+	pass.AuthToken = token
+	pass.CreatedAt = now
+	pass.UpdatedAt = now
+
+	if pass.WalletID != nil {
+		wallet, err := s.repo.GetWalletInfo(ctx, *pass.WalletID)
+		if err != nil {
+			return err
+		}
+		if wallet != nil {
+			pass.Balance = wallet.Balance
+		}
+	}
+
+	return s.repo.CreatePass(ctx, pass)
+}
+
+// renderApplePass builds the .pkpass bundle of a pass from its current content
+func (s *Service) renderApplePass(ctx context.Context, pass *Pass, signer *appleSigner) ([]byte, error) {
+	var p *applePass
+	switch pass.Kind {
+	case KindTicket:
+		ticket, barcode, schedule, err := s.ticketContent(ctx, pass)
+		if err != nil {
+			return nil, err
+		}
+		p = ticketApplePass(ticket, barcode, schedule)
+	default:
+		wallet, err := s.walletContent(ctx, pass)
+		if err != nil {
+			return nil, err
+		}
+		p = balanceApplePass(wallet)
+	}
+
+	p.SerialNumber = pass.SerialNumber()
+	p.OrganizationName = s.config.OrganizationName
+	if s.config.WebServiceURL != "" {
+		p.WebServiceURL = strings.TrimSuffix(s.config.WebServiceURL, "/")
+		p.AuthenticationToken = pass.AuthToken
+	}
+
+	return buildPKPass(p, signer, s.now())
+}
+
+// googlePayload returns the save link payload of a pass: its class and object
+func (s *Service) googlePayload(ctx context.Context, pass *Pass) (map[string]interface{}, error) {
+	switch pass.Kind {
+	case KindTicket:
+		ticket, barcode, schedule, err := s.ticketContent(ctx, pass)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"eventTicketClasses": []interface{}{ticketGoogleClass(pass.PassTypeID, s.config.OrganizationName, ticket)},
+			"eventTicketObjects": []interface{}{ticketGoogleObject(pass, ticket, barcode, schedule)},
+		}, nil
+	default:
+		wallet, err := s.walletContent(ctx, pass)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"genericClasses": []interface{}{googleGenericClass{ID: googleBalanceClassID(pass.PassTypeID, wallet.FestivalID.String())}},
+			"genericObjects": []interface{}{balanceGoogleObject(pass, wallet)},
+		}, nil
+	}
+}
+
+func (s *Service) ticketContent(ctx context.Context, pass *Pass) (*TicketInfo, string, []ScheduleDay, error) {
+	if pass.TicketID == nil {
+		return nil, "", nil, ErrTicketNotFound
+	}
+	ticket, err := s.repo.GetTicketInfo(ctx, *pass.TicketID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if ticket == nil {
+		return nil, "", nil, ErrTicketNotFound
+	}
+
+	schedule, err := s.repo.GetSchedule(ctx, ticket.FestivalID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// A ticket transferred away keeps its pass, voided and without its code
+	barcode := ""
+	if s.barcodes != nil && ticket.Status == "VALID" && ticket.UserID != nil && *ticket.UserID == pass.UserID {
+		barcode, err = s.barcodes.GenerateQRDataOnly(ticket.ID, pass.UserID, ticket.FestivalID, ticket.Code, ticket.ValidUntil)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to generate ticket QR code: %w", err)
+		}
+	}
+	if ticket.UserID == nil || *ticket.UserID != pass.UserID {
+		ticket.Status = "TRANSFERRED"
+	}
+
+	return ticket, barcode, schedule, nil
+}
+
+func (s *Service) walletContent(ctx context.Context, pass *Pass) (*WalletInfo, error) {
+	if pass.WalletID == nil {
+		return nil, ErrWalletNotFound
+	}
+	wallet, err := s.repo.GetWalletInfo(ctx, *pass.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// Apple Wallet web service
+
+// RegisterDevice registers a device for push updates of a pass, reporting whether the
+// registration is new
+func (s *Service) RegisterDevice(ctx context.Context, deviceLibraryID, passTypeID, serial, authToken, pushToken string) (bool, error) {
+	pass, err := s.authenticate(ctx, passTypeID, serial, authToken)
+	if err != nil {
+		return false, err
+	}
+
+	return s.repo.CreateRegistration(ctx, &Registration{
+		ID:              uuid.New(),
+		PassID:          pass.ID,
+		DeviceLibraryID: deviceLibraryID,
+		PushToken:       pushToken,
+		CreatedAt:       s.now(),
+	})
+}
+
+// UnregisterDevice stops push updates of a pass to a device
+func (s *Service) UnregisterDevice(ctx context.Context, deviceLibraryID, passTypeID, serial, authToken string) error {
+	pass, err := s.authenticate(ctx, passTypeID, serial, authToken)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteRegistration(ctx, deviceLibraryID, pass.ID)
+}
+
+// UpdatedSerials returns the serial numbers of the passes of a device updated since
+// the tag it got on its previous call, or nil when none were
+func (s *Service) UpdatedSerials(ctx context.Context, deviceLibraryID, passTypeID, since string) (*UpdatedSerials, error) {
+	var sinceTime time.Time
+	if since != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, since)
+		if err == nil {
+			sinceTime = parsed
+		}
+	}
+
+	passes, err := s.repo.GetUpdatedSerials(ctx, deviceLibraryID, passTypeID, sinceTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(passes) == 0 {
+		return nil, nil
+	}
+
+	updated := &UpdatedSerials{SerialNumbers: make([]string, len(passes))}
+	var last time.Time
+	for i, pass := range passes {
+		updated.SerialNumbers[i] = pass.SerialNumber()
+		if pass.UpdatedAt.After(last) {
+			last = pass.UpdatedAt
+		}
+	}
+	updated.LastUpdated = last.UTC().Format(time.RFC3339Nano)
+	return updated, nil
+}
+
+// LatestPass returns the current .pkpass bundle of a pass and when it last changed
+func (s *Service) LatestPass(ctx context.Context, passTypeID, serial, authToken string) ([]byte, time.Time, error) {
+	pass, err := s.authenticate(ctx, passTypeID, serial, authToken)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	signer, _, err := s.appleCredentials(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if signer.PassTypeID != pass.PassTypeID {
+		return nil, time.Time{}, ErrNoActiveCertificate
+	}
+
+	bundle, err := s.renderApplePass(ctx, pass, signer)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return bundle, pass.UpdatedAt, nil
+}
+
+// authenticate finds an Apple pass by pass type and serial number and checks the token
+// it was issued with
+func (s *Service) authenticate(ctx context.Context, passTypeID, serial, authToken string) (*Pass, error) {
+	id, err := uuid.Parse(serial)
+	if err != nil {
+		return nil, ErrPassNotFound
+	}
+	pass, err := s.repo.GetPassByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pass == nil || pass.Platform != PlatformApple || pass.PassTypeID != passTypeID {
+		return nil, ErrPassNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(pass.AuthToken), []byte(authToken)) != 1 {
+		return nil, ErrUnauthorizedDevice
+	}
+	return pass, nil
+}
+
+// Updates
+
+// RefreshPasses pushes the passes whose ticket, festival, schedule or balance changed
+// since they were last pushed: Apple devices are notified to fetch the new version
+// and Google Wallet objects are patched. It returns the number of passes updated.
+func (s *Service) RefreshPasses(ctx context.Context) (int, error) {
+	passes, err := s.repo.FindStalePasses(ctx, RefreshBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(passes) == 0 {
+		return 0, nil
+	}
+
+	updater := &passUpdater{service: s}
+	updated := 0
+	for i := range passes {
+		pass := &passes[i]
+		if err := updater.update(ctx, pass); err != nil {
+			log.Error().Err(err).Str("pass_id", pass.ID.String()).Msg("Failed to update wallet pass")
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// HandleRefreshPasses processes the periodic pass update job
+func (s *Service) HandleRefreshPasses(ctx context.Context, t *asynq.Task) error {
+	start := time.Now()
+	updated, err := s.RefreshPasses(ctx)
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		log.Info().Int("updated", updated).Dur("duration", time.Since(start)).Msg("Updated wallet passes")
+	}
+	return nil
+}
+
+// passUpdater loads the credentials of each platform once per run
+type passUpdater struct {
+	service *Service
+
+	apple     *appleSigner
+	appleTLS  *tls.Certificate
+	appleErr  error
+	google    *googleSigner
+	googleErr error
+	loaded    map[Platform]bool
+}
+
+func (u *passUpdater) update(ctx context.Context, pass *Pass) error {
+	s := u.service
+	if u.loaded == nil {
+		u.loaded = make(map[Platform]bool)
+	}
+
+	// Record the change first: Apple devices ask for the passes updated since their
+	// last fetch once notified
+	pass.UpdatedAt = s.now()
+	if pass.Kind == KindBalance {
+		wallet, err := s.walletContent(ctx, pass)
+		if err != nil {
+			return err
+		}
+		pass.Balance = wallet.Balance
+	}
+	if err := s.repo.UpdatePass(ctx, pass); err != nil {
+		return err
+	}
+
+	switch pass.Platform {
+	case PlatformApple:
+		if !u.loaded[PlatformApple] {
+			u.apple, u.appleTLS, u.appleErr = s.appleCredentials(ctx)
+			u.loaded[PlatformApple] = true
+		}
+		if u.appleErr != nil {
+			return u.appleErr
+		}
+		return s.pushApple(ctx, pass, u.appleTLS)
+
+	default:
+		if !u.loaded[PlatformGoogle] {
+			u.google, u.googleErr = s.googleCredentials(ctx)
+			u.loaded[PlatformGoogle] = true
+		}
+		if u.googleErr != nil {
+			return u.googleErr
+		}
+		return s.patchGoogle(ctx, pass, u.google)
+	}
+}
+
+func (s *Service) pushApple(ctx context.Context, pass *Pass, cert *tls.Certificate) error {
+	registrations, err := s.repo.GetRegistrations(ctx, pass.ID)
+	if err != nil {
+		return err
+	}
+	if len(registrations) == 0 {
+		return nil
+	}
+
+	tokens := make([]string, len(registrations))
+	for i, r := range registrations {
+		tokens[i] = r.PushToken
+	}
+
+	gone, pushErr := s.apns.push(ctx, *cert, pass.PassTypeID, tokens)
+	for _, token := range gone {
+		if err := s.repo.DeleteRegistrationsByPushToken(ctx, token); err != nil {
+			log.Warn().Err(err).Msg("Failed to delete pass registrations of an expired push token")
+		}
+	}
+	return pushErr
+}
+
+func (s *Service) patchGoogle(ctx context.Context, pass *Pass, signer *googleSigner) error {
+	if signer.IssuerID != pass.PassTypeID {
+		return fmt.Errorf("pass was issued by Google Wallet issuer %s, which is not active", pass.PassTypeID)
+	}
+
+	payload, err := s.googlePayload(ctx, pass)
+	if err != nil {
+		return err
+	}
+	for key, resource := range map[string]string{"eventTicketObjects": "eventTicketObject", "genericObjects": "genericObject"} {
+		objects, ok := payload[key].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, object := range objects {
+			if err := s.google.patchObject(ctx, signer, resource, pass.GoogleObjectID(), object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func generateAuthToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate pass token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
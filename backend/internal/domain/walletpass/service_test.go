@@ -0,0 +1,516 @@
+package walletpass
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEncryptor reverses strings, enough to tell encrypted keys from plain ones
+type fakeEncryptor struct{}
+
+func (fakeEncryptor) EncryptString(plaintext string) (string, error) {
+	return "enc:" + reverse(plaintext), nil
+}
+
+func (fakeEncryptor) DecryptString(ciphertext string) (string, error) {
+	return reverse(strings.TrimPrefix(ciphertext, "enc:")), nil
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+type fakeBarcoder struct{}
+
+func (fakeBarcoder) GenerateQRDataOnly(ticketID, userID, festivalID uuid.UUID, ticketCode string, validUntil time.Time) (string, error) {
+	return "signed:" + ticketCode, nil
+}
+
+// testCredentials is a self-signed pass type certificate, the WWDR chain and the key
+type testCredentials struct {
+	certPEM, chainPEM, keyPEM string
+	cert                      *x509.Certificate
+}
+
+func newTestCredentials(t *testing.T, passTypeID string, notAfter time.Time) testCredentials {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test WWDR"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject: pkix.Name{
+			CommonName:         "Pass Type ID: " + passTypeID,
+			OrganizationalUnit: []string{"TEAM123456"},
+			ExtraNames:         []pkix.AttributeTypeAndValue{{Type: oidUserID, Value: passTypeID}},
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCredentials{
+		certPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		chainPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		keyPEM:   string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		cert:     cert,
+	}
+}
+
+func newTestServiceAccount(t *testing.T) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	account, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "wallet@festivals.iam.gserviceaccount.com",
+	})
+	require.NoError(t, err)
+	return string(account), key
+}
+
+func newTestService(repo Repository) *Service {
+	service := NewService(repo, fakeEncryptor{}, fakeBarcoder{}, Config{
+		WebServiceURL:    "https://api.example.com/api/v1/passes",
+		OrganizationName: "Festivals",
+	})
+	now := time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+// expectUpload expects the first certificate of a platform to be stored and activated,
+// and serves it as the active certificate afterwards
+func expectUpload(mockRepo *MockRepository, platform Platform) {
+	stored := &Certificate{}
+	mockRepo.On("CreateCertificate", mock.Anything, mock.AnythingOfType("*walletpass.Certificate")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*Certificate) }).
+		Return(nil).Once()
+	mockRepo.On("GetActiveCertificate", mock.Anything, platform).Return(nil, nil).Once()
+	mockRepo.On("ActivateCertificate", mock.Anything, mock.AnythingOfType("*walletpass.Certificate"), mock.Anything).Return(nil).Once()
+	mockRepo.On("GetActiveCertificate", mock.Anything, platform).Return(stored, nil)
+}
+
+func uploadApple(t *testing.T, service *Service, mockRepo *MockRepository) {
+	t.Helper()
+	expectUpload(mockRepo, PlatformApple)
+	creds := newTestCredentials(t, "pass.io.festivals.ticket", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := service.UploadCertificate(context.Background(), UploadCertificateRequest{
+		Platform:       PlatformApple,
+		Name:           "Pass type 2026",
+		CertificatePEM: creds.certPEM,
+		ChainPEM:       creds.chainPEM,
+		PrivateKeyPEM:  creds.keyPEM,
+	}, nil)
+	require.NoError(t, err)
+}
+
+func testTicket(userID uuid.UUID) *TicketInfo {
+	return &TicketInfo{
+		ID:           uuid.New(),
+		UserID:       &userID,
+		FestivalID:   uuid.New(),
+		Code:         "TKT-ABC123",
+		HolderName:   "Alex Martin",
+		Status:       "VALID",
+		TypeName:     "Weekend Pass",
+		ValidFrom:    time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC),
+		ValidUntil:   time.Date(2026, 7, 13, 0, 0, 0, 0, time.UTC),
+		Color:        "#7C3AED",
+		FestivalName: "Summer Sound",
+		Location:     "Brussels",
+		Timezone:     "Europe/Brussels",
+		StartDate:    time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2026, 7, 12, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// expectTicket serves a ticket and the schedule of its festival
+func expectTicket(mockRepo *MockRepository, ticket *TicketInfo) {
+	mockRepo.On("GetTicketInfo", mock.Anything, ticket.ID).Return(ticket, nil)
+	mockRepo.On("GetSchedule", mock.Anything, ticket.FestivalID).Return([]ScheduleDay{{
+		Day:   "2026-07-10",
+		Start: time.Date(2026, 7, 10, 12, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 7, 10, 23, 30, 0, 0, time.UTC),
+	}}, nil)
+}
+
+// issueTicketPass issues the first Apple pass of a ticket and returns its record
+func issueTicketPass(t *testing.T, service *Service, mockRepo *MockRepository, userID uuid.UUID, ticket *TicketInfo) (*IssuedPassFile, *Pass) {
+	t.Helper()
+	var pass *Pass
+	mockRepo.On("GetTicketPass", mock.Anything, ticket.ID, PlatformApple).Return(nil, nil).Once()
+	mockRepo.On("CreatePass", mock.Anything, mock.AnythingOfType("*walletpass.Pass")).
+		Run(func(args mock.Arguments) { pass = args.Get(1).(*Pass) }).
+		Return(nil).Once()
+
+	issued, err := service.IssueTicketPass(context.Background(), userID, ticket.ID, PlatformApple)
+	require.NoError(t, err)
+	require.NotNil(t, pass)
+	return issued, pass
+}
+
+func readBundle(t *testing.T, bundle []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = data
+	}
+	return files
+}
+
+// TestSignDetached tests that the manifest signature is a detached SignedData whose
+// signed attributes carry the manifest digest and verify with the certificate
+func TestSignDetached(t *testing.T) {
+	creds := newTestCredentials(t, "pass.io.festivals.ticket", time.Now().Add(24*time.Hour))
+	key, err := parsePrivateKeyPEM(creds.keyPEM)
+	require.NoError(t, err)
+	chain, err := parseCertificatesPEM(creds.chainPEM)
+	require.NoError(t, err)
+
+	content := []byte(`{"pass.json":"abc"}`)
+	der, err := signDetached(content, creds.cert, chain, key, time.Now())
+	require.NoError(t, err)
+
+	var info contentInfo
+	_, err = asn1.Unmarshal(der, &info)
+	require.NoError(t, err)
+	assert.True(t, info.ContentType.Equal(oidSignedData))
+
+	var sd signedData
+	_, err = asn1.Unmarshal(info.Content.Bytes, &sd)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sd.Version)
+	assert.True(t, sd.EncapContentInfo.ContentType.Equal(oidData))
+	require.Len(t, sd.SignerInfos, 1)
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	require.NoError(t, err)
+	assert.Len(t, certs, 2, "the pass certificate and the WWDR chain")
+
+	signer := sd.SignerInfos[0]
+	assert.Equal(t, creds.cert.SerialNumber, signer.IssuerAndSerial.SerialNumber)
+
+	// The signature covers the attributes re-tagged as a SET
+	attrs := append([]byte{}, signer.SignedAttributes.FullBytes...)
+	attrs[0] = 0x31
+	assert.NoError(t, creds.cert.CheckSignature(x509.SHA256WithRSA, attrs, signer.Signature))
+
+	var parsed []attribute
+	_, err = asn1.UnmarshalWithParams(attrs, &parsed, "set")
+	require.NoError(t, err)
+	digest := sha256.Sum256(content)
+	found := false
+	for _, attr := range parsed {
+		if attr.Type.Equal(oidMessageDigest) {
+			var value []byte
+			_, err := asn1.Unmarshal(attr.Values.Bytes, &value)
+			require.NoError(t, err)
+			assert.Equal(t, digest[:], value)
+			found = true
+		}
+	}
+	assert.True(t, found, "message digest attribute")
+}
+
+// TestUploadCertificate tests that Apple certificates are validated, read for their
+// pass type and team, stored encrypted and activated when first
+func TestUploadCertificate(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	creds := newTestCredentials(t, "pass.io.festivals.ticket", time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC))
+
+	var stored *Certificate
+	mockRepo.On("CreateCertificate", mock.Anything, mock.AnythingOfType("*walletpass.Certificate")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*Certificate) }).
+		Return(nil).Once()
+	mockRepo.On("GetActiveCertificate", mock.Anything, PlatformApple).Return(nil, nil).Once()
+	mockRepo.On("ActivateCertificate", mock.Anything, mock.AnythingOfType("*walletpass.Certificate"), mock.Anything).Return(nil).Once()
+
+	cert, err := service.UploadCertificate(context.Background(), UploadCertificateRequest{
+		Platform:       PlatformApple,
+		Name:           "Pass type 2026",
+		CertificatePEM: creds.certPEM,
+		ChainPEM:       creds.chainPEM,
+		PrivateKeyPEM:  creds.keyPEM,
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "pass.io.festivals.ticket", cert.Identifier)
+	assert.Equal(t, "TEAM123456", cert.TeamID)
+	assert.True(t, cert.Active)
+	assert.True(t, cert.Expiring, "expires within 30 days")
+	require.NotNil(t, stored)
+	assert.True(t, strings.HasPrefix(stored.PrivateKey, "enc:"))
+	assert.NotContains(t, stored.PrivateKey, "PRIVATE KEY")
+
+	other := newTestCredentials(t, "pass.io.festivals.ticket", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err = service.UploadCertificate(context.Background(), UploadCertificateRequest{
+		Platform:       PlatformApple,
+		Name:           "Mismatched key",
+		CertificatePEM: creds.certPEM,
+		ChainPEM:       creds.chainPEM,
+		PrivateKeyPEM:  other.keyPEM,
+	}, nil)
+	assert.ErrorIs(t, err, ErrInvalidCertificate)
+
+	mockRepo.On("GetCertificate", mock.Anything, cert.ID).Return(stored, nil)
+	err = service.DeleteCertificate(context.Background(), cert.ID)
+	assert.ErrorIs(t, err, ErrCertificateActive)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "DeleteCertificate", mock.Anything, mock.Anything)
+}
+
+// TestIssueTicketPass_Apple tests the .pkpass bundle of a ticket and the web service
+// authentication of the pass
+func TestIssueTicketPass_Apple(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	uploadApple(t, service, mockRepo)
+	userID := uuid.New()
+	ticket := testTicket(userID)
+	expectTicket(mockRepo, ticket)
+
+	_, err := service.IssueTicketPass(context.Background(), uuid.New(), ticket.ID, PlatformApple)
+	assert.ErrorIs(t, err, ErrTicketNotFound, "tickets of other users")
+
+	issued, pass := issueTicketPass(t, service, mockRepo, userID, ticket)
+	files := readBundle(t, issued.PKPass)
+	for _, name := range []string{"pass.json", "manifest.json", "signature", "icon.png", "icon@2x.png"} {
+		assert.Contains(t, files, name)
+	}
+
+	var manifest map[string]string
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	sum := sha1.Sum(files["pass.json"])
+	assert.Equal(t, hex.EncodeToString(sum[:]), manifest["pass.json"])
+
+	var p applePass
+	require.NoError(t, json.Unmarshal(files["pass.json"], &p))
+	assert.Equal(t, issued.PassID, pass.ID)
+	assert.Equal(t, "pass.io.festivals.ticket", p.PassTypeIdentifier)
+	assert.Equal(t, "TEAM123456", p.TeamIdentifier)
+	assert.Equal(t, pass.SerialNumber(), p.SerialNumber)
+	assert.Equal(t, pass.AuthToken, p.AuthenticationToken)
+	assert.Equal(t, "https://api.example.com/api/v1/passes", p.WebServiceURL)
+	require.Len(t, p.Barcodes, 1)
+	assert.Equal(t, "signed:TKT-ABC123", p.Barcodes[0].Message)
+	require.NotNil(t, p.EventTicket)
+	assert.Equal(t, "14:00 - 01:30", p.EventTicket.BackFields[0].Value, "schedule in festival time")
+
+	// Issued again from its record
+	mockRepo.On("GetTicketPass", mock.Anything, ticket.ID, PlatformApple).Return(pass, nil).Once()
+	again, err := service.IssueTicketPass(context.Background(), userID, ticket.ID, PlatformApple)
+	require.NoError(t, err)
+	assert.Equal(t, issued.PassID, again.PassID)
+
+	mockRepo.On("GetPassByID", mock.Anything, pass.ID).Return(pass, nil)
+	_, _, err = service.LatestPass(context.Background(), pass.PassTypeID, pass.SerialNumber(), "wrong")
+	assert.ErrorIs(t, err, ErrUnauthorizedDevice)
+	_, updatedAt, err := service.LatestPass(context.Background(), pass.PassTypeID, pass.SerialNumber(), pass.AuthToken)
+	require.NoError(t, err)
+	assert.Equal(t, pass.UpdatedAt, updatedAt)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "CreatePass", 1)
+}
+
+// TestIssueBalancePass_Google tests the save link of a balance card
+func TestIssueBalancePass_Google(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	account, key := newTestServiceAccount(t)
+	expectUpload(mockRepo, PlatformGoogle)
+	_, err := service.UploadCertificate(context.Background(), UploadCertificateRequest{
+		Platform:       PlatformGoogle,
+		Name:           "Issuer",
+		IssuerID:       "3388000000012345678",
+		ServiceAccount: account,
+	}, nil)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	wallet := &WalletInfo{
+		ID:           uuid.New(),
+		UserID:       &userID,
+		FestivalID:   uuid.New(),
+		Balance:      2550,
+		Status:       "ACTIVE",
+		FestivalName: "Summer Sound",
+		CurrencyName: "Jetons",
+		ExchangeRate: 0.5,
+		EndDate:      time.Date(2026, 7, 12, 0, 0, 0, 0, time.UTC),
+	}
+	mockRepo.On("GetUserWalletID", mock.Anything, userID, wallet.FestivalID).Return(wallet.ID, nil)
+	mockRepo.On("GetBalancePass", mock.Anything, wallet.ID, PlatformGoogle).Return(nil, nil)
+	mockRepo.On("GetWalletInfo", mock.Anything, wallet.ID).Return(wallet, nil)
+	mockRepo.On("CreatePass", mock.Anything, mock.MatchedBy(func(p *Pass) bool {
+		return p.Kind == KindBalance && p.Balance == 2550 && p.PassTypeID == "3388000000012345678"
+	})).Return(nil).Once()
+
+	issued, err := service.IssueBalancePass(context.Background(), userID, wallet.FestivalID, PlatformGoogle)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(issued.SaveURL, GoogleSaveURL))
+	assert.Nil(t, issued.PKPass)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(strings.TrimPrefix(issued.SaveURL, GoogleSaveURL), claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "savetowallet", claims["typ"])
+
+	payload := claims["payload"].(map[string]interface{})
+	object := payload["genericObjects"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "3388000000012345678."+issued.PassID.String(), object["id"])
+	header := object["header"].(map[string]interface{})["defaultValue"].(map[string]interface{})
+	assert.Equal(t, "51 Jetons", header["value"])
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestRefreshPasses_Apple tests that changed passes are marked updated, pushed to the
+// registered devices and listed as updated, and that expired push tokens are dropped
+func TestRefreshPasses_Apple(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	uploadApple(t, service, mockRepo)
+	userID := uuid.New()
+	ticket := testTicket(userID)
+	expectTicket(mockRepo, ticket)
+
+	_, pass := issueTicketPass(t, service, mockRepo, userID, ticket)
+	mockRepo.On("GetPassByID", mock.Anything, pass.ID).Return(pass, nil)
+
+	registration := func(device, token string) interface{} {
+		return mock.MatchedBy(func(r *Registration) bool {
+			return r.PassID == pass.ID && r.DeviceLibraryID == device && r.PushToken == token
+		})
+	}
+	mockRepo.On("CreateRegistration", mock.Anything, registration("device-1", "token-ok")).Return(true, nil).Once()
+	mockRepo.On("CreateRegistration", mock.Anything, registration("device-1", "token-ok")).Return(false, nil).Once()
+	mockRepo.On("CreateRegistration", mock.Anything, registration("device-2", "token-gone")).Return(true, nil).Once()
+
+	created, err := service.RegisterDevice(context.Background(), "device-1", pass.PassTypeID, pass.SerialNumber(), pass.AuthToken, "token-ok")
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = service.RegisterDevice(context.Background(), "device-1", pass.PassTypeID, pass.SerialNumber(), pass.AuthToken, "token-ok")
+	require.NoError(t, err)
+	assert.False(t, created)
+	_, err = service.RegisterDevice(context.Background(), "device-2", pass.PassTypeID, pass.SerialNumber(), pass.AuthToken, "token-gone")
+	require.NoError(t, err)
+
+	since := pass.UpdatedAt
+	sinceTag := mock.MatchedBy(func(t time.Time) bool { return t.Equal(since) })
+	mockRepo.On("GetUpdatedSerials", mock.Anything, "device-1", pass.PassTypeID, sinceTag).Return([]Pass{}, nil).Once()
+	updated, err := service.UpdatedSerials(context.Background(), "device-1", pass.PassTypeID, since.Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	assert.Nil(t, updated, "nothing changed yet")
+
+	var pushed []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, pass.PassTypeID, r.Header.Get("apns-topic"))
+		token := strings.TrimPrefix(r.URL.Path, "/3/device/")
+		pushed = append(pushed, token)
+		if token == "token-gone" {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer server.Close()
+	service.apns.baseURL = server.URL
+	service.apns.newClient = func(cert tls.Certificate) *http.Client { return server.Client() }
+
+	later := since.Add(time.Hour)
+	service.now = func() time.Time { return later }
+
+	stale := *pass
+	mockRepo.On("FindStalePasses", mock.Anything, RefreshBatchSize).Return([]Pass{stale}, nil).Once()
+	mockRepo.On("UpdatePass", mock.Anything, mock.MatchedBy(func(p *Pass) bool {
+		return p.ID == pass.ID && p.UpdatedAt.Equal(later)
+	})).Return(nil).Once()
+	mockRepo.On("GetRegistrations", mock.Anything, pass.ID).Return([]Registration{
+		{PassID: pass.ID, DeviceLibraryID: "device-1", PushToken: "token-ok"},
+		{PassID: pass.ID, DeviceLibraryID: "device-2", PushToken: "token-gone"},
+	}, nil).Once()
+	mockRepo.On("DeleteRegistrationsByPushToken", mock.Anything, "token-gone").Return(nil).Once()
+
+	count, err := service.RefreshPasses(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.ElementsMatch(t, []string{"token-ok", "token-gone"}, pushed)
+
+	refreshed := stale
+	refreshed.UpdatedAt = later
+	mockRepo.On("GetUpdatedSerials", mock.Anything, "device-1", pass.PassTypeID, sinceTag).Return([]Pass{refreshed}, nil).Once()
+	updated, err = service.UpdatedSerials(context.Background(), "device-1", pass.PassTypeID, since.Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	require.NotNil(t, updated)
+	assert.Equal(t, []string{pass.SerialNumber()}, updated.SerialNumbers)
+	assert.Equal(t, later.Format(time.RFC3339Nano), updated.LastUpdated)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "DeleteRegistrationsByPushToken", mock.Anything, "token-ok")
+}
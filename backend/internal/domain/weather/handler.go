@@ -0,0 +1,118 @@
+package weather
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	weather := r.Group("/weather")
+	{
+		weather.GET("", h.List)
+		weather.POST("/sync", h.Sync)
+	}
+}
+
+// List lists the hourly weather observations of the festival
+// @Summary List weather observations
+// @Description Get the hourly weather observed at the festival location, the last 24 hours by default
+// @Tags weather
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time (RFC3339)"
+// @Success 200 {object} response.Response{data=[]ObservationResponse} "Weather observations"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/weather [get]
+func (h *Handler) List(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var query ListObservationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "VALIDATION_ERROR", "Invalid query parameters", err.Error())
+		return
+	}
+
+	observations, err := h.service.List(c.Request.Context(), festivalID, query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]ObservationResponse, len(observations))
+	for i := range observations {
+		items[i] = observations[i].ToResponse()
+	}
+
+	response.OK(c, items)
+}
+
+// Sync backfills the weather observations of the festival from the provider
+// @Summary Sync weather observations
+// @Description Fetch and store the hourly weather of a past period from the configured provider
+// @Tags weather
+// @Accept json
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param request body SyncRequest true "Period to backfill"
+// @Success 200 {object} response.Response{data=SyncResult} "Observations stored"
+// @Failure 400 {object} response.ErrorResponse "Invalid request or festival without coordinates"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Festival not found"
+// @Failure 503 {object} response.ErrorResponse "Weather provider not configured"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/weather/sync [post]
+func (h *Handler) Sync(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+
+	var req SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	result, err := h.service.Sync(c.Request.Context(), festivalID, req.From, req.To)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.OK(c, result)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrFestivalNotFound):
+		response.NotFound(c, "Festival not found")
+	case errors.Is(err, ErrMissingCoordinates):
+		response.BadRequest(c, "MISSING_COORDINATES", err.Error(), nil)
+	case errors.Is(err, ErrInvalidRange):
+		response.BadRequest(c, "INVALID_RANGE", err.Error(), nil)
+	case errors.Is(err, ErrProviderUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,92 @@
+package weather
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/weather"
+)
+
+// MaxSyncRange is the longest period that can be backfilled in one request
+const MaxSyncRange = 31 * 24 * time.Hour
+
+// Weather errors
+var (
+	ErrFestivalNotFound    = errors.New("festival not found")
+	ErrMissingCoordinates  = errors.New("festival location has no coordinates")
+	ErrProviderUnavailable = errors.New("weather provider not configured")
+	ErrInvalidRange        = errors.New("invalid time range")
+)
+
+// Observation is the weather at a festival location over one hour
+type Observation struct {
+	ID              uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FestivalID      uuid.UUID         `json:"festivalId" gorm:"type:uuid;not null;index"`
+	ObservedAt      time.Time         `json:"observedAt" gorm:"not null"` // Start of the hour, UTC
+	TemperatureC    *float64          `json:"temperatureC,omitempty"`
+	PrecipitationMM *float64          `json:"precipitationMm,omitempty" gorm:"column:precipitation_mm"`
+	WindSpeedKMH    *float64          `json:"windSpeedKmh,omitempty" gorm:"column:wind_speed_kmh"`
+	CloudCoverPct   *float64          `json:"cloudCoverPct,omitempty"`
+	WeatherCode     *int              `json:"weatherCode,omitempty"`
+	Condition       weather.Condition `json:"condition" gorm:"not null"`
+	Provider        string            `json:"provider" gorm:"not null"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+}
+
+func (Observation) TableName() string {
+	return "weather_observations"
+}
+
+// FestivalLocation is the position weather is ingested for
+type FestivalLocation struct {
+	ID        uuid.UUID `gorm:"column:id"`
+	Latitude  *float64  `gorm:"column:latitude"`
+	Longitude *float64  `gorm:"column:longitude"`
+}
+
+// ListObservationsQuery filters the observations of a festival
+type ListObservationsQuery struct {
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// SyncRequest represents the request to backfill weather observations
+type SyncRequest struct {
+	From time.Time `json:"from" binding:"required"`
+	To   time.Time `json:"to" binding:"required,gtfield=From"`
+}
+
+// SyncResult reports how many observations were stored
+type SyncResult struct {
+	Provider     string    `json:"provider"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Observations int       `json:"observations"`
+}
+
+// ObservationResponse represents the API response for a weather observation
+type ObservationResponse struct {
+	ObservedAt      string            `json:"observedAt"`
+	TemperatureC    *float64          `json:"temperatureC,omitempty"`
+	PrecipitationMM *float64          `json:"precipitationMm,omitempty"`
+	WindSpeedKMH    *float64          `json:"windSpeedKmh,omitempty"`
+	CloudCoverPct   *float64          `json:"cloudCoverPct,omitempty"`
+	WeatherCode     *int              `json:"weatherCode,omitempty"`
+	Condition       weather.Condition `json:"condition"`
+	Wet             bool              `json:"wet"`
+}
+
+func (o *Observation) ToResponse() ObservationResponse {
+	return ObservationResponse{
+		ObservedAt:      o.ObservedAt.Format(time.RFC3339),
+		TemperatureC:    o.TemperatureC,
+		PrecipitationMM: o.PrecipitationMM,
+		WindSpeedKMH:    o.WindSpeedKMH,
+		CloudCoverPct:   o.CloudCoverPct,
+		WeatherCode:     o.WeatherCode,
+		Condition:       o.Condition,
+		Wet:             o.Condition.IsWet(),
+	}
+}
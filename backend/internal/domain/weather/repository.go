@@ -0,0 +1,88 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	UpsertObservations(ctx context.Context, observations []Observation) error
+	ListObservations(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]Observation, error)
+	GetFestivalLocation(ctx context.Context, festivalID uuid.UUID) (*FestivalLocation, error)
+	ListFestivalsInProgress(ctx context.Context, at time.Time) ([]FestivalLocation, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// UpsertObservations stores observations, replacing those already stored for the same hour
+func (r *repository) UpsertObservations(ctx context.Context, observations []Observation) error {
+	if len(observations) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "festival_id"}, {Name: "observed_at"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"temperature_c", "precipitation_mm", "wind_speed_kmh", "cloud_cover_pct",
+			"weather_code", "condition", "provider", "updated_at",
+		}),
+	}).Create(&observations).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert weather observations: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListObservations(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]Observation, error) {
+	var observations []Observation
+	err := r.db.WithContext(ctx).
+		Where("festival_id = ? AND observed_at >= ? AND observed_at < ?", festivalID, from, to).
+		Order("observed_at ASC").
+		Find(&observations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list weather observations: %w", err)
+	}
+	return observations, nil
+}
+
+func (r *repository) GetFestivalLocation(ctx context.Context, festivalID uuid.UUID) (*FestivalLocation, error) {
+	var location FestivalLocation
+	err := r.db.WithContext(ctx).Table("public.festivals").
+		Select("id, latitude, longitude").
+		Where("id = ?", festivalID).
+		Take(&location).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get festival location: %w", err)
+	}
+	return &location, nil
+}
+
+// ListFestivalsInProgress lists the geocoded festivals running at the given time,
+// including the day before and after so set-up and tear-down are covered
+func (r *repository) ListFestivalsInProgress(ctx context.Context, at time.Time) ([]FestivalLocation, error) {
+	var locations []FestivalLocation
+	err := r.db.WithContext(ctx).Table("public.festivals").
+		Select("id, latitude, longitude").
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Where("status IN ?", []string{"ACTIVE", "COMPLETED"}).
+		Where("start_date - INTERVAL '1 day' <= ? AND end_date + INTERVAL '2 days' > ?", at, at).
+		Scan(&locations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list festivals in progress: %w", err)
+	}
+	return locations, nil
+}
@@ -0,0 +1,138 @@
+package weather
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/weather"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeIngestWeather is the task type of the periodic weather ingestion
+const TypeIngestWeather = "weather:ingest"
+
+// ingestLookback is re-fetched on every run so late provider corrections are picked up
+const ingestLookback = 24 * time.Hour
+
+type Service struct {
+	repo     Repository
+	provider weather.Provider
+}
+
+// NewService creates a weather service; provider may be nil when ingestion is disabled
+func NewService(repo Repository, provider weather.Provider) *Service {
+	return &Service{repo: repo, provider: provider}
+}
+
+// List returns the stored observations of a festival, the last 24 hours by default
+func (s *Service) List(ctx context.Context, festivalID uuid.UUID, query ListObservationsQuery) ([]Observation, error) {
+	to := time.Now()
+	if query.To != nil {
+		to = *query.To
+	}
+	from := to.Add(-24 * time.Hour)
+	if query.From != nil {
+		from = *query.From
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidRange
+	}
+
+	return s.repo.ListObservations(ctx, festivalID, from, to)
+}
+
+// Sync fetches and stores the observations of a festival between from and to
+func (s *Service) Sync(ctx context.Context, festivalID uuid.UUID, from, to time.Time) (*SyncResult, error) {
+	if s.provider == nil {
+		return nil, ErrProviderUnavailable
+	}
+	if !from.Before(to) || to.Sub(from) > MaxSyncRange {
+		return nil, ErrInvalidRange
+	}
+
+	location, err := s.repo.GetFestivalLocation(ctx, festivalID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, ErrFestivalNotFound
+	}
+	if location.Latitude == nil || location.Longitude == nil {
+		return nil, ErrMissingCoordinates
+	}
+
+	stored, err := s.ingest(ctx, *location, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyncResult{
+		Provider:     s.provider.Name(),
+		From:         from,
+		To:           to,
+		Observations: stored,
+	}, nil
+}
+
+// HandleIngestWeather processes the periodic ingestion task for all festivals in progress
+func (s *Service) HandleIngestWeather(ctx context.Context, t *asynq.Task) error {
+	if s.provider == nil {
+		return nil
+	}
+
+	now := time.Now()
+	festivals, err := s.repo.ListFestivalsInProgress(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, festival := range festivals {
+		stored, err := s.ingest(ctx, festival, now.Add(-ingestLookback), now)
+		if err != nil {
+			log.Error().Err(err).Str("festival_id", festival.ID.String()).Msg("Failed to ingest weather")
+			continue
+		}
+		log.Debug().Str("festival_id", festival.ID.String()).Int("observations", stored).Msg("Ingested weather")
+	}
+
+	return nil
+}
+
+// ingest stores the provider observations that fall within [from, to)
+func (s *Service) ingest(ctx context.Context, location FestivalLocation, from, to time.Time) (int, error) {
+	fetched, err := s.provider.GetHourly(ctx, *location.Latitude, *location.Longitude, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	from = from.Truncate(time.Hour)
+	now := time.Now()
+	observations := make([]Observation, 0, len(fetched))
+	for _, o := range fetched {
+		// The provider returns whole days and forecasts; keep past hours in range only
+		if o.Time.Before(from) || !o.Time.Before(to) || o.Time.After(now) {
+			continue
+		}
+		observations = append(observations, Observation{
+			ID:              uuid.New(),
+			FestivalID:      location.ID,
+			ObservedAt:      o.Time,
+			TemperatureC:    o.TemperatureC,
+			PrecipitationMM: o.PrecipitationMM,
+			WindSpeedKMH:    o.WindSpeedKMH,
+			CloudCoverPct:   o.CloudCoverPct,
+			WeatherCode:     o.WeatherCode,
+			Condition:       o.Condition,
+			Provider:        s.provider.Name(),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+	}
+
+	if err := s.repo.UpsertObservations(ctx, observations); err != nil {
+		return 0, err
+	}
+	return len(observations), nil
+}
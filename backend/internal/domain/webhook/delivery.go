@@ -33,6 +33,7 @@ type WebhookDelivery struct {
 	Payload       string         `json:"payload" gorm:"type:text;not null"`
 	Signature     string         `json:"signature" gorm:"not null"`
 	Status        DeliveryStatus `json:"status" gorm:"default:'PENDING';index"`
+	PriorityClass PriorityClass  `json:"priorityClass" gorm:"default:'STANDARD'"` // Of the webhook when the event occurred
 	AttemptCount  int            `json:"attemptCount" gorm:"default:0"`
 	MaxAttempts   int            `json:"maxAttempts" gorm:"default:5"`
 	NextRetryAt   *time.Time     `json:"nextRetryAt,omitempty" gorm:"index"`
@@ -243,6 +244,7 @@ type DeliveryResponse struct {
 	EventID       uuid.UUID      `json:"eventId"`
 	EventType     string         `json:"eventType"`
 	Status        DeliveryStatus `json:"status"`
+	PriorityClass PriorityClass  `json:"priorityClass"`
 	AttemptCount  int            `json:"attemptCount"`
 	MaxAttempts   int            `json:"maxAttempts"`
 	NextRetryAt   *string        `json:"nextRetryAt,omitempty"`
@@ -266,17 +268,18 @@ func (d *WebhookDelivery) ToResponse() DeliveryResponse {
 	}
 
 	return DeliveryResponse{
-		ID:           d.ID,
-		WebhookID:    d.WebhookID,
-		EventID:      d.EventID,
-		EventType:    string(d.EventType),
-		Status:       d.Status,
-		AttemptCount: d.AttemptCount,
-		MaxAttempts:  d.MaxAttempts,
-		NextRetryAt:  nextRetryAt,
-		DeliveredAt:  deliveredAt,
-		LastError:    d.LastError,
-		CreatedAt:    d.CreatedAt.Format(time.RFC3339),
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		EventID:       d.EventID,
+		EventType:     string(d.EventType),
+		Status:        d.Status,
+		PriorityClass: d.PriorityClass,
+		AttemptCount:  d.AttemptCount,
+		MaxAttempts:   d.MaxAttempts,
+		NextRetryAt:   nextRetryAt,
+		DeliveredAt:   deliveredAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -73,6 +73,16 @@ func (e EventType) Category() string {
 	}
 }
 
+// IsPayment tells whether the event is about money changing hands, which priority
+// webhooks get delivered first
+func (e EventType) IsPayment() bool {
+	switch e {
+	case EventOrderPaid, EventOrderRefunded, EventWalletTopUp, EventWalletPayment:
+		return true
+	}
+	return false
+}
+
 // Event represents a webhook event to be delivered
 type Event struct {
 	ID         uuid.UUID   `json:"id"`
@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the festival-scoped delivery metrics of the webhooks, which
+// should be restricted to organizers
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/webhooks/:webhookId/latency", h.GetLatency)
+}
+
+// RegisterAdminRoutes registers the priority classes of the webhooks, set by the
+// platform admins along with the paid plans
+func (h *Handler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.PUT("/webhooks/:webhookId/priority-class", h.SetPriorityClass)
+}
+
+// GetLatency returns the delivery latency of a webhook
+// @Summary Get webhook delivery latency
+// @Description Latency from the events to their successful delivery to the webhook, queueing and retries included, as percentiles over the window and the p95 per hour, with the deliveries still waiting. The endpoint response time is averaged over the successful attempts.
+// @Tags webhooks
+// @Produce json
+// @Param festivalId path string true "Festival ID" format(uuid)
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param hours query int false "Window in hours, at most 168" default(24)
+// @Success 200 {object} LatencyStats
+// @Failure 400 {object} response.ErrorResponse "Invalid ID or window"
+// @Failure 404 {object} response.ErrorResponse "Webhook not found"
+// @Security BearerAuth
+// @Router /festivals/{festivalId}/webhooks/{webhookId}/latency [get]
+func (h *Handler) GetLatency(c *gin.Context) {
+	festivalID, err := uuid.Parse(c.GetString("festival_id"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_FESTIVAL_ID", "Invalid festival ID", nil)
+		return
+	}
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid webhook ID", nil)
+		return
+	}
+
+	var window time.Duration
+	if hours := c.Query("hours"); hours != "" {
+		n, err := strconv.Atoi(hours)
+		if err != nil || n < 1 || time.Duration(n)*time.Hour > MaxLatencyWindow {
+			response.BadRequest(c, "INVALID_WINDOW", "hours must be between 1 and 168", nil)
+			return
+		}
+		window = time.Duration(n) * time.Hour
+	}
+
+	stats, err := h.service.GetLatencyStats(c.Request.Context(), festivalID, webhookID, window)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.OK(c, stats)
+}
+
+// SetPriorityClass sets the priority class of a webhook
+// @Summary Set webhook priority class
+// @Description Set the class the deliveries of a webhook are queued in: PRIORITY for the paid plans, whose payment events are delivered first, STANDARD or BULK. Deliveries already queued keep their class.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param request body SetPriorityClassRequest true "Priority class"
+// @Success 200 {object} WebhookConfigResponse
+// @Failure 400 {object} response.ErrorResponse "Invalid priority class"
+// @Failure 404 {object} response.ErrorResponse "Webhook not found"
+// @Security BearerAuth
+// @Router /admin/webhooks/{webhookId}/priority-class [put]
+func (h *Handler) SetPriorityClass(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		response.BadRequest(c, "INVALID_ID", "Invalid webhook ID", nil)
+		return
+	}
+
+	var req SetPriorityClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationFailed(c, err)
+		return
+	}
+
+	webhook, err := h.service.SetPriorityClass(c.Request.Context(), webhookID, req.PriorityClass)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.OK(c, webhook.ToResponse())
+}
+
+func handleError(c *gin.Context, err error) {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		response.InternalError(c, err.Error())
+		return
+	}
+	switch appErr.Code {
+	case "WEBHOOK_NOT_FOUND":
+		response.NotFound(c, appErr.Message)
+	case "INVALID_PRIORITY_CLASS":
+		response.BadRequest(c, appErr.Code, appErr.Message, nil)
+	case errors.ErrCodeForbidden:
+		response.Forbidden(c, appErr.Message)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -16,6 +16,25 @@ const (
 	WebhookStatusDisabled WebhookStatus = "DISABLED" // Manually disabled by admin
 )
 
+// PriorityClass orders the deliveries of the webhooks when they queue up, e.g. after an
+// outage of the worker or of the receivers. Each class has its own queue.
+type PriorityClass string
+
+const (
+	PriorityClassPriority PriorityClass = "PRIORITY" // Paid plans, granted by the platform admins
+	PriorityClassStandard PriorityClass = "STANDARD"
+	PriorityClassBulk     PriorityClass = "BULK" // Exports and analytics sinks, delivered last
+)
+
+// IsValid checks if the priority class is known
+func (c PriorityClass) IsValid() bool {
+	switch c {
+	case PriorityClassPriority, PriorityClassStandard, PriorityClassBulk:
+		return true
+	}
+	return false
+}
+
 // WebhookConfig represents a webhook configuration
 type WebhookConfig struct {
 	ID              uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -26,6 +45,7 @@ type WebhookConfig struct {
 	Secret          string            `json:"-" gorm:"not null"` // Never expose in JSON
 	Events          []EventType       `json:"events" gorm:"type:text[];serializer:json"`
 	Status          WebhookStatus     `json:"status" gorm:"default:'ACTIVE'"`
+	PriorityClass   PriorityClass     `json:"priorityClass" gorm:"default:'STANDARD'"`
 	Headers         map[string]string `json:"headers" gorm:"type:jsonb;default:'{}'"`
 	MaxRetries      int               `json:"maxRetries" gorm:"default:5"`
 	TimeoutSeconds  int               `json:"timeoutSeconds" gorm:"default:30"`
@@ -97,6 +117,7 @@ type CreateWebhookRequest struct {
 	Headers        map[string]string `json:"headers,omitempty"`
 	MaxRetries     *int              `json:"maxRetries,omitempty"`
 	TimeoutSeconds *int              `json:"timeoutSeconds,omitempty"`
+	PriorityClass  *PriorityClass    `json:"priorityClass,omitempty"` // STANDARD or BULK, PRIORITY is granted by the admins
 }
 
 // UpdateWebhookRequest represents the request to update a webhook
@@ -109,6 +130,13 @@ type UpdateWebhookRequest struct {
 	Status         *WebhookStatus    `json:"status,omitempty"`
 	MaxRetries     *int              `json:"maxRetries,omitempty"`
 	TimeoutSeconds *int              `json:"timeoutSeconds,omitempty"`
+	PriorityClass  *PriorityClass    `json:"priorityClass,omitempty"` // STANDARD or BULK, PRIORITY is granted by the admins
+}
+
+// SetPriorityClassRequest represents the request of an admin to set the priority class
+// of a webhook
+type SetPriorityClassRequest struct {
+	PriorityClass PriorityClass `json:"priorityClass" binding:"required"`
 }
 
 // TestWebhookRequest represents a request to send a test webhook
@@ -130,6 +158,7 @@ type WebhookConfigResponse struct {
 	URL             string            `json:"url"`
 	Events          []EventType       `json:"events"`
 	Status          WebhookStatus     `json:"status"`
+	PriorityClass   PriorityClass     `json:"priorityClass"`
 	Headers         map[string]string `json:"headers,omitempty"`
 	MaxRetries      int               `json:"maxRetries"`
 	TimeoutSeconds  int               `json:"timeoutSeconds"`
@@ -165,6 +194,7 @@ func (w *WebhookConfig) ToResponse() WebhookConfigResponse {
 		URL:             w.URL,
 		Events:          w.Events,
 		Status:          w.Status,
+		PriorityClass:   w.PriorityClass,
 		Headers:         w.Headers,
 		MaxRetries:      w.MaxRetries,
 		TimeoutSeconds:  w.TimeoutSeconds,
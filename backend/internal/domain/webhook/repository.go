@@ -3,6 +3,7 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,6 +35,7 @@ type Repository interface {
 	// Statistics
 	GetDeliveryStats(ctx context.Context, webhookID uuid.UUID, since time.Time) (*DeliveryStats, error)
 	GetFestivalDeliveryStats(ctx context.Context, festivalID uuid.UUID, since time.Time) (*DeliveryStats, error)
+	GetLatencyStats(ctx context.Context, webhookID uuid.UUID, since time.Time) (*LatencyStats, error)
 
 	// Cleanup
 	DeleteOldDeliveries(ctx context.Context, olderThan time.Time) (int64, error)
@@ -158,11 +160,13 @@ func (r *repository) GetDeliveriesByWebhook(ctx context.Context, webhookID uuid.
 	return deliveries, total, nil
 }
 
-// GetPendingDeliveries retrieves pending deliveries for processing
+// GetPendingDeliveries retrieves pending deliveries for processing, the priority ones
+// first so that they are queued first when the pending deliveries pile up
 func (r *repository) GetPendingDeliveries(ctx context.Context, limit int) ([]WebhookDelivery, error) {
 	var deliveries []WebhookDelivery
 	err := r.db.WithContext(ctx).
 		Where("status = ?", DeliveryStatusPending).
+		Order("CASE priority_class WHEN 'PRIORITY' THEN 0 WHEN 'BULK' THEN 2 ELSE 1 END").
 		Order("created_at ASC").
 		Limit(limit).
 		Find(&deliveries).Error
@@ -224,6 +228,30 @@ type DeliveryStats struct {
 	SuccessRate        float64 `json:"successRate"`
 }
 
+// LatencyStats holds the delivery latency of a webhook: the time from an event to its
+// successful delivery, the waits in the queue and the retries included
+type LatencyStats struct {
+	WebhookID         uuid.UUID       `json:"webhookId"`
+	PriorityClass     PriorityClass   `json:"priorityClass"`
+	Since             time.Time       `json:"since"`
+	Delivered         int64           `json:"delivered"`
+	P50Ms             int64           `json:"p50Ms"`
+	P95Ms             int64           `json:"p95Ms"`
+	P99Ms             int64           `json:"p99Ms"`
+	MaxMs             int64           `json:"maxMs"`
+	AvgResponseTimeMs int64           `json:"avgResponseTimeMs"` // Of the endpoint, successful attempts only
+	Backlog           int64           `json:"backlog"`           // Pending and retrying deliveries, whenever created
+	OldestWaitingAt   *time.Time      `json:"oldestWaitingAt,omitempty"`
+	Hourly            []LatencyBucket `json:"hourly"`
+}
+
+// LatencyBucket is the latency of the deliveries of a webhook delivered within an hour
+type LatencyBucket struct {
+	Hour      time.Time `json:"hour"`
+	Delivered int64     `json:"delivered"`
+	P95Ms     int64     `json:"p95Ms"`
+}
+
 // GetDeliveryStats retrieves delivery statistics for a webhook
 func (r *repository) GetDeliveryStats(ctx context.Context, webhookID uuid.UUID, since time.Time) (*DeliveryStats, error) {
 	var stats DeliveryStats
@@ -356,6 +384,91 @@ func (r *repository) GetFestivalDeliveryStats(ctx context.Context, festivalID uu
 	return &stats, nil
 }
 
+// GetLatencyStats retrieves the latency of the deliveries of a webhook delivered since a
+// time, overall and per hour, and the deliveries still waiting
+func (r *repository) GetLatencyStats(ctx context.Context, webhookID uuid.UUID, since time.Time) (*LatencyStats, error) {
+	var summary struct {
+		Delivered int64
+		P50       float64
+		P95       float64
+		P99       float64
+		Max       float64
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COUNT(*) AS delivered,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY latency), 0) AS p50,
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency), 0) AS p95,
+			COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY latency), 0) AS p99,
+			COALESCE(MAX(latency), 0) AS max
+		FROM (
+			SELECT EXTRACT(EPOCH FROM delivered_at - created_at) * 1000 AS latency
+			FROM webhook_deliveries
+			WHERE webhook_id = ? AND status = ? AND delivered_at >= ?
+		) delivered`, webhookID, DeliveryStatusDelivered, since).
+		Scan(&summary).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery latency: %w", err)
+	}
+
+	stats := &LatencyStats{
+		Delivered: summary.Delivered,
+		P50Ms:     int64(math.Round(summary.P50)),
+		P95Ms:     int64(math.Round(summary.P95)),
+		P99Ms:     int64(math.Round(summary.P99)),
+		MaxMs:     int64(math.Round(summary.Max)),
+	}
+
+	var avgResponseTime float64
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(AVG(a.response_time), 0)
+		FROM webhook_delivery_attempts a
+		INNER JOIN webhook_deliveries d ON d.id = a.delivery_id
+		WHERE d.webhook_id = ? AND a.success = true AND a.attempted_at >= ?`, webhookID, since).
+		Scan(&avgResponseTime).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint response time: %w", err)
+	}
+	stats.AvgResponseTimeMs = int64(math.Round(avgResponseTime))
+
+	var backlog struct {
+		Waiting int64
+		Oldest  *time.Time
+	}
+	err = r.db.WithContext(ctx).Model(&WebhookDelivery{}).
+		Select("COUNT(*) AS waiting, MIN(created_at) AS oldest").
+		Where("webhook_id = ?", webhookID).
+		Where("status IN ?", []DeliveryStatus{DeliveryStatusPending, DeliveryStatusRetrying}).
+		Scan(&backlog).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery backlog: %w", err)
+	}
+	stats.Backlog = backlog.Waiting
+	stats.OldestWaitingAt = backlog.Oldest
+
+	var hours []struct {
+		Hour      time.Time
+		Delivered int64
+		P95       float64
+	}
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT date_trunc('hour', delivered_at) AS hour, COUNT(*) AS delivered,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM delivered_at - created_at) * 1000) AS p95
+		FROM webhook_deliveries
+		WHERE webhook_id = ? AND status = ? AND delivered_at >= ?
+		GROUP BY 1
+		ORDER BY 1`, webhookID, DeliveryStatusDelivered, since).
+		Scan(&hours).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly delivery latency: %w", err)
+	}
+	stats.Hourly = make([]LatencyBucket, len(hours))
+	for i, h := range hours {
+		stats.Hourly[i] = LatencyBucket{Hour: h.Hour.UTC(), Delivered: h.Delivered, P95Ms: int64(math.Round(h.P95))}
+	}
+
+	return stats, nil
+}
+
 // ============================================================================
 // Cleanup
 // ============================================================================
@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRepository implements Repository interface
+var _ Repository = (*MockRepository)(nil)
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
+}
+
+func (m *MockRepository) GetWebhookByID(ctx context.Context, id uuid.UUID) (*WebhookConfig, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WebhookConfig), args.Error(1)
+}
+
+func (m *MockRepository) GetWebhooksByFestival(ctx context.Context, festivalID uuid.UUID) ([]WebhookConfig, error) {
+	args := m.Called(ctx, festivalID)
+	return args.Get(0).([]WebhookConfig), args.Error(1)
+}
+
+func (m *MockRepository) GetActiveWebhooksForEvent(ctx context.Context, festivalID uuid.UUID, eventType EventType) ([]WebhookConfig, error) {
+	args := m.Called(ctx, festivalID, eventType)
+	return args.Get(0).([]WebhookConfig), args.Error(1)
+}
+
+func (m *MockRepository) CreateWebhook(ctx context.Context, webhook *WebhookConfig) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateWebhook(ctx context.Context, webhook *WebhookConfig) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetDeliveryByID(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) GetDeliveriesByWebhook(ctx context.Context, webhookID uuid.UUID, offset, limit int) ([]WebhookDelivery, int64, error) {
+	args := m.Called(ctx, webhookID, offset, limit)
+	return args.Get(0).([]WebhookDelivery), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetPendingDeliveries(ctx context.Context, limit int) ([]WebhookDelivery, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) GetDeliveriesForRetry(ctx context.Context, limit int) ([]WebhookDelivery, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) CreateDeliveryAttempt(ctx context.Context, attempt *DeliveryAttempt) error {
+	args := m.Called(ctx, attempt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetAttemptsByDelivery(ctx context.Context, deliveryID uuid.UUID) ([]DeliveryAttempt, error) {
+	args := m.Called(ctx, deliveryID)
+	return args.Get(0).([]DeliveryAttempt), args.Error(1)
+}
+
+func (m *MockRepository) GetDeliveryStats(ctx context.Context, webhookID uuid.UUID, since time.Time) (*DeliveryStats, error) {
+	args := m.Called(ctx, webhookID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DeliveryStats), args.Error(1)
+}
+
+func (m *MockRepository) GetFestivalDeliveryStats(ctx context.Context, festivalID uuid.UUID, since time.Time) (*DeliveryStats, error) {
+	args := m.Called(ctx, festivalID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DeliveryStats), args.Error(1)
+}
+
+func (m *MockRepository) GetLatencyStats(ctx context.Context, webhookID uuid.UUID, since time.Time) (*LatencyStats, error) {
+	args := m.Called(ctx, webhookID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LatencyStats), args.Error(1)
+}
+
+func (m *MockRepository) DeleteOldDeliveries(ctx context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -13,6 +13,13 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// DefaultLatencyWindow and MaxLatencyWindow bound how far back the delivery latency of a
+// webhook is computed
+const (
+	DefaultLatencyWindow = 24 * time.Hour
+	MaxLatencyWindow     = 7 * 24 * time.Hour
+)
+
 // Service handles webhook business logic
 type Service struct {
 	repo        Repository
@@ -79,6 +86,14 @@ func (s *Service) CreateWebhook(ctx context.Context, festivalID uuid.UUID, req C
 		timeoutSeconds = *req.TimeoutSeconds
 	}
 
+	priorityClass := PriorityClassStandard
+	if req.PriorityClass != nil {
+		if err := validateOrganizerPriorityClass(*req.PriorityClass); err != nil {
+			return nil, err
+		}
+		priorityClass = *req.PriorityClass
+	}
+
 	webhook := &WebhookConfig{
 		ID:             uuid.New(),
 		FestivalID:     festivalID,
@@ -88,6 +103,7 @@ func (s *Service) CreateWebhook(ctx context.Context, festivalID uuid.UUID, req C
 		Secret:         secret,
 		Events:         req.Events,
 		Status:         WebhookStatusActive,
+		PriorityClass:  priorityClass,
 		Headers:        req.Headers,
 		MaxRetries:     maxRetries,
 		TimeoutSeconds: timeoutSeconds,
@@ -175,6 +191,12 @@ func (s *Service) UpdateWebhook(ctx context.Context, id uuid.UUID, req UpdateWeb
 	if req.TimeoutSeconds != nil {
 		webhook.TimeoutSeconds = *req.TimeoutSeconds
 	}
+	if req.PriorityClass != nil && *req.PriorityClass != webhook.PriorityClass {
+		if err := validateOrganizerPriorityClass(*req.PriorityClass); err != nil {
+			return nil, err
+		}
+		webhook.PriorityClass = *req.PriorityClass
+	}
 
 	webhook.UpdatedAt = time.Now().UTC()
 
@@ -210,6 +232,37 @@ func (s *Service) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// SetPriorityClass sets the priority class of a webhook, from the platform admins: the
+// priority class comes with the paid plans. The deliveries already queued keep the
+// class they were created with.
+func (s *Service) SetPriorityClass(ctx context.Context, id uuid.UUID, class PriorityClass) (*WebhookConfig, error) {
+	if !class.IsValid() {
+		return nil, errors.New("INVALID_PRIORITY_CLASS", fmt.Sprintf("Invalid priority class: %s", class))
+	}
+
+	webhook, err := s.repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "WEBHOOK_FETCH_FAILED", "Failed to fetch webhook")
+	}
+	if webhook == nil {
+		return nil, errors.New("WEBHOOK_NOT_FOUND", "Webhook not found")
+	}
+
+	webhook.PriorityClass = class
+	webhook.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, errors.Wrap(err, "WEBHOOK_UPDATE_FAILED", "Failed to update webhook")
+	}
+
+	log.Info().
+		Str("webhook_id", id.String()).
+		Str("priority_class", string(class)).
+		Msg("Webhook priority class set")
+
+	return webhook, nil
+}
+
 // RegenerateSecret generates a new secret for a webhook
 func (s *Service) RegenerateSecret(ctx context.Context, id uuid.UUID) (string, error) {
 	webhook, err := s.repo.GetWebhookByID(ctx, id)
@@ -295,6 +348,10 @@ func (s *Service) createDelivery(ctx context.Context, webhook *WebhookConfig, ev
 	if err != nil {
 		return fmt.Errorf("failed to create delivery: %w", err)
 	}
+	delivery.PriorityClass = webhook.PriorityClass
+	if !delivery.PriorityClass.IsValid() {
+		delivery.PriorityClass = PriorityClassStandard
+	}
 
 	// Save delivery to database
 	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
@@ -302,7 +359,7 @@ func (s *Service) createDelivery(ctx context.Context, webhook *WebhookConfig, ev
 	}
 
 	// Enqueue for async processing
-	if err := s.enqueueDelivery(ctx, delivery.ID); err != nil {
+	if err := s.enqueueDelivery(ctx, delivery); err != nil {
 		log.Error().
 			Err(err).
 			Str("delivery_id", delivery.ID.String()).
@@ -371,7 +428,7 @@ func (s *Service) ProcessDelivery(ctx context.Context, deliveryID uuid.UUID) err
 
 		if delivery.ScheduleRetry(errMsg) {
 			// Enqueue for retry
-			if err := s.enqueueDeliveryAt(ctx, delivery.ID, *delivery.NextRetryAt); err != nil {
+			if err := s.enqueueDeliveryAt(ctx, delivery, *delivery.NextRetryAt); err != nil {
 				log.Error().
 					Err(err).
 					Str("delivery_id", delivery.ID.String()).
@@ -498,7 +555,7 @@ func (s *Service) RetryDelivery(ctx context.Context, deliveryID uuid.UUID) error
 	}
 
 	// Enqueue for immediate processing
-	if err := s.enqueueDelivery(ctx, deliveryID); err != nil {
+	if err := s.enqueueDelivery(ctx, delivery); err != nil {
 		return errors.Wrap(err, "DELIVERY_ENQUEUE_FAILED", "Failed to enqueue delivery")
 	}
 
@@ -523,6 +580,35 @@ func (s *Service) GetFestivalWebhookStats(ctx context.Context, festivalID uuid.U
 	return s.repo.GetFestivalDeliveryStats(ctx, festivalID, since)
 }
 
+// GetLatencyStats retrieves the delivery latency of a webhook of a festival over the
+// last window, one day by default and at most MaxLatencyWindow
+func (s *Service) GetLatencyStats(ctx context.Context, festivalID, webhookID uuid.UUID, window time.Duration) (*LatencyStats, error) {
+	if window <= 0 {
+		window = DefaultLatencyWindow
+	}
+	if window > MaxLatencyWindow {
+		window = MaxLatencyWindow
+	}
+
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		return nil, errors.Wrap(err, "WEBHOOK_FETCH_FAILED", "Failed to fetch webhook")
+	}
+	if webhook == nil || webhook.FestivalID != festivalID {
+		return nil, errors.New("WEBHOOK_NOT_FOUND", "Webhook not found")
+	}
+
+	since := time.Now().UTC().Add(-window)
+	stats, err := s.repo.GetLatencyStats(ctx, webhookID, since)
+	if err != nil {
+		return nil, errors.Wrap(err, "WEBHOOK_STATS_FAILED", "Failed to fetch webhook latency")
+	}
+	stats.WebhookID = webhook.ID
+	stats.PriorityClass = webhook.PriorityClass
+	stats.Since = since
+	return stats, nil
+}
+
 // ============================================================================
 // Background Jobs
 // ============================================================================
@@ -534,8 +620,9 @@ func (s *Service) ProcessPendingDeliveries(ctx context.Context, limit int) error
 		return fmt.Errorf("failed to get pending deliveries: %w", err)
 	}
 
-	for _, delivery := range deliveries {
-		if err := s.enqueueDelivery(ctx, delivery.ID); err != nil {
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if err := s.enqueueDelivery(ctx, delivery); err != nil {
 			log.Error().
 				Err(err).
 				Str("delivery_id", delivery.ID.String()).
@@ -553,8 +640,9 @@ func (s *Service) ProcessRetryDeliveries(ctx context.Context, limit int) error {
 		return fmt.Errorf("failed to get deliveries for retry: %w", err)
 	}
 
-	for _, delivery := range deliveries {
-		if err := s.enqueueDelivery(ctx, delivery.ID); err != nil {
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if err := s.enqueueDelivery(ctx, delivery); err != nil {
 			log.Error().
 				Err(err).
 				Str("delivery_id", delivery.ID.String()).
@@ -596,25 +684,43 @@ func generateSecret(length int) (string, error) {
 	return "whsec_" + hex.EncodeToString(bytes), nil
 }
 
+// validateOrganizerPriorityClass checks a priority class organizers pick for their
+// webhooks: the priority class is granted by the platform admins only
+func validateOrganizerPriorityClass(class PriorityClass) error {
+	if !class.IsValid() {
+		return errors.New("INVALID_PRIORITY_CLASS", fmt.Sprintf("Invalid priority class: %s", class))
+	}
+	if class == PriorityClassPriority {
+		return errors.ForbiddenErr("The priority class comes with a paid plan, contact support to enable it")
+	}
+	return nil
+}
+
 // enqueueDelivery enqueues a delivery for immediate processing
-func (s *Service) enqueueDelivery(ctx context.Context, deliveryID uuid.UUID) error {
+func (s *Service) enqueueDelivery(ctx context.Context, delivery *WebhookDelivery) error {
 	if s.queueClient == nil {
 		// Fallback to synchronous processing if no queue client
-		return s.ProcessDelivery(ctx, deliveryID)
+		return s.ProcessDelivery(ctx, delivery.ID)
 	}
 
-	task := NewWebhookDeliveryTask(deliveryID)
-	_, err := s.queueClient.EnqueueTask(ctx, task)
+	task, err := NewWebhookDeliveryTask(delivery)
+	if err != nil {
+		return err
+	}
+	_, err = s.queueClient.EnqueueTask(ctx, task)
 	return err
 }
 
 // enqueueDeliveryAt enqueues a delivery for processing at a specific time
-func (s *Service) enqueueDeliveryAt(ctx context.Context, deliveryID uuid.UUID, processAt time.Time) error {
+func (s *Service) enqueueDeliveryAt(ctx context.Context, delivery *WebhookDelivery, processAt time.Time) error {
 	if s.queueClient == nil {
 		return nil // Can't schedule without queue
 	}
 
-	task := NewWebhookDeliveryTask(deliveryID)
-	_, err := s.queueClient.EnqueueScheduled(ctx, task, processAt)
+	task, err := NewWebhookDeliveryTask(delivery)
+	if err != nil {
+		return err
+	}
+	_, err = s.queueClient.EnqueueScheduled(ctx, task, processAt)
 	return err
 }
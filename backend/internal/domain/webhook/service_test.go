@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(repo Repository) *Service {
+	sender := NewSender(SenderConfig{Timeout: 5 * time.Second, UserAgent: "test", AllowInsecure: true})
+	config := DefaultServiceConfig()
+	config.AllowInsecure = true
+	return NewService(repo, sender, nil, config)
+}
+
+// expectCreateWebhook returns the webhook the service creates, which the repository
+// returns by ID from then on
+func expectCreateWebhook(mockRepo *MockRepository) *WebhookConfig {
+	stored := &WebhookConfig{}
+	mockRepo.On("CreateWebhook", mock.Anything, mock.AnythingOfType("*webhook.WebhookConfig")).
+		Run(func(args mock.Arguments) {
+			*stored = *args.Get(1).(*WebhookConfig)
+			mockRepo.On("GetWebhookByID", mock.Anything, stored.ID).Return(stored, nil)
+		}).
+		Return(nil).Once()
+	return stored
+}
+
+func TestDeliveryQueue(t *testing.T) {
+	assert.Equal(t, queue.QueueWebhooksPriority, DeliveryQueue(PriorityClassPriority, EventOrderPaid))
+	assert.Equal(t, queue.QueueWebhooksPriority, DeliveryQueue(PriorityClassPriority, EventWalletPayment))
+	assert.Equal(t, queue.QueueWebhooksStandard, DeliveryQueue(PriorityClassPriority, EventTicketScanned), "only the payments of a priority webhook go first")
+	assert.Equal(t, queue.QueueWebhooksStandard, DeliveryQueue(PriorityClassStandard, EventOrderPaid))
+	assert.Equal(t, queue.QueueWebhooksStandard, DeliveryQueue("", EventOrderPaid), "deliveries created before the classes")
+	assert.Equal(t, queue.QueueWebhooksBulk, DeliveryQueue(PriorityClassBulk, EventOrderPaid))
+}
+
+func TestService_OrganizerPriorityClass(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	ctx := context.Background()
+
+	priority, bulk := PriorityClassPriority, PriorityClassBulk
+	_, err := service.CreateWebhook(ctx, festivalID, CreateWebhookRequest{
+		Name: "ERP", URL: "https://erp.example.com/hooks", Events: []EventType{EventOrderPaid}, PriorityClass: &priority,
+	}, nil)
+	assert.True(t, errors.IsForbidden(err), "the priority class comes with a paid plan")
+	mockRepo.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+
+	stored := expectCreateWebhook(mockRepo)
+	created, err := service.CreateWebhook(ctx, festivalID, CreateWebhookRequest{
+		Name: "ERP", URL: "https://erp.example.com/hooks", Events: []EventType{EventOrderPaid},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, PriorityClassStandard, created.PriorityClass)
+
+	_, err = service.UpdateWebhook(ctx, created.ID, UpdateWebhookRequest{PriorityClass: &priority})
+	assert.True(t, errors.IsForbidden(err))
+	mockRepo.AssertNotCalled(t, "UpdateWebhook", mock.Anything, mock.Anything)
+
+	mockRepo.On("UpdateWebhook", mock.Anything, stored).Return(nil).Times(3)
+
+	updated, err := service.SetPriorityClass(ctx, created.ID, PriorityClassPriority)
+	require.NoError(t, err)
+	assert.Equal(t, PriorityClassPriority, updated.PriorityClass)
+
+	// Saving the webhook as it is keeps the class the admins granted
+	name := "ERP production"
+	updated, err = service.UpdateWebhook(ctx, created.ID, UpdateWebhookRequest{Name: &name, PriorityClass: &priority})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityClassPriority, updated.PriorityClass)
+
+	updated, err = service.UpdateWebhook(ctx, created.ID, UpdateWebhookRequest{PriorityClass: &bulk})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityClassBulk, updated.PriorityClass, "organizers can step down")
+
+	_, err = service.SetPriorityClass(ctx, created.ID, "URGENT")
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_DispatchEventKeepsPriorityClass(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	webhook := &WebhookConfig{
+		ID: uuid.New(), FestivalID: festivalID, URL: server.URL, Secret: "whsec_test",
+		Events: []EventType{EventOrderPaid}, Status: WebhookStatusActive, PriorityClass: PriorityClassPriority, MaxRetries: 3,
+	}
+	mockRepo.On("GetActiveWebhooksForEvent", mock.Anything, festivalID, EventOrderPaid).Return([]WebhookConfig{*webhook}, nil).Once()
+	mockRepo.On("GetWebhookByID", mock.Anything, webhook.ID).Return(webhook, nil).Once()
+	mockRepo.On("UpdateWebhook", mock.Anything, webhook).Return(nil).Once()
+
+	delivery := &WebhookDelivery{}
+	mockRepo.On("CreateDelivery", mock.Anything, mock.AnythingOfType("*webhook.WebhookDelivery")).
+		Run(func(args mock.Arguments) {
+			*delivery = *args.Get(1).(*WebhookDelivery)
+			mockRepo.On("GetDeliveryByID", mock.Anything, delivery.ID).Return(delivery, nil).Once()
+		}).
+		Return(nil).Once()
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil).Once()
+	var attempts []DeliveryAttempt
+	mockRepo.On("CreateDeliveryAttempt", mock.Anything, mock.AnythingOfType("*webhook.DeliveryAttempt")).
+		Run(func(args mock.Arguments) { attempts = append(attempts, *args.Get(1).(*DeliveryAttempt)) }).
+		Return(nil)
+
+	// Without a queue the delivery is sent right away
+	require.NoError(t, service.DispatchEvent(context.Background(), NewEvent(EventOrderPaid, festivalID, map[string]any{"order_id": "1"})))
+	assert.Equal(t, PriorityClassPriority, delivery.PriorityClass)
+	assert.Equal(t, DeliveryStatusDelivered, delivery.Status)
+	require.Len(t, attempts, 1)
+	assert.True(t, attempts[0].Success)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetLatencyStats(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := newTestService(mockRepo)
+	festivalID := uuid.New()
+	webhook := &WebhookConfig{ID: uuid.New(), FestivalID: festivalID, PriorityClass: PriorityClassPriority}
+	mockRepo.On("GetWebhookByID", mock.Anything, webhook.ID).Return(webhook, nil)
+	var since time.Time
+	mockRepo.On("GetLatencyStats", mock.Anything, webhook.ID, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { since = args.Get(2).(time.Time) }).
+		Return(&LatencyStats{Delivered: 12, P50Ms: 180, P95Ms: 950, P99Ms: 2400, MaxMs: 3100}, nil).Twice()
+	ctx := context.Background()
+
+	stats, err := service.GetLatencyStats(ctx, festivalID, webhook.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.ID, stats.WebhookID)
+	assert.Equal(t, PriorityClassPriority, stats.PriorityClass)
+	assert.Equal(t, int64(950), stats.P95Ms)
+	assert.Equal(t, since, stats.Since)
+	assert.WithinDuration(t, time.Now().Add(-DefaultLatencyWindow), stats.Since, time.Minute)
+
+	stats, err = service.GetLatencyStats(ctx, festivalID, webhook.ID, 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-MaxLatencyWindow), stats.Since, time.Minute)
+
+	_, err = service.GetLatencyStats(ctx, uuid.New(), webhook.ID, time.Hour)
+	assert.Error(t, err, "webhooks of another festival are not found")
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+)
+
+// TypeDeliverWebhook is the worker task sending a webhook delivery
+const TypeDeliverWebhook = "webhook:deliver"
+
+// DeliveryTaskPayload is the payload of a delivery task
+type DeliveryTaskPayload struct {
+	DeliveryID uuid.UUID `json:"deliveryId"`
+}
+
+// NewWebhookDeliveryTask creates the task sending a delivery, on the queue of its
+// priority class. The task is not retried by asynq: a failed delivery schedules its own
+// retry with backoff.
+func NewWebhookDeliveryTask(delivery *WebhookDelivery) (*asynq.Task, error) {
+	data, err := json.Marshal(DeliveryTaskPayload{DeliveryID: delivery.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeDeliverWebhook, data, asynq.Queue(DeliveryQueue(delivery.PriorityClass, delivery.EventType)), asynq.MaxRetry(0)), nil
+}
+
+// DeliveryQueue returns the queue a delivery waits in. Only the payment events of a
+// priority webhook go through the priority queue, so that a priority webhook subscribed
+// to every ticket scan cannot hold back the payments of the others; its other events
+// are delivered like the standard ones.
+func DeliveryQueue(class PriorityClass, eventType EventType) string {
+	switch class {
+	case PriorityClassPriority:
+		if eventType.IsPayment() {
+			return queue.QueueWebhooksPriority
+		}
+		return queue.QueueWebhooksStandard
+	case PriorityClassBulk:
+		return queue.QueueWebhooksBulk
+	default:
+		return queue.QueueWebhooksStandard
+	}
+}
+
+// HandleDeliverWebhook handles a delivery task
+func (s *Service) HandleDeliverWebhook(ctx context.Context, t *asynq.Task) error {
+	var payload DeliveryTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return s.ProcessDelivery(ctx, payload.DeliveryID)
+}
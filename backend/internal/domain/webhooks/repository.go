@@ -2,6 +2,7 @@ package webhooks
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,4 +21,5 @@ type Repository interface {
 	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error
 	GetDeliveriesByWebhook(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]WebhookDelivery, int64, error)
 	GetDeliveryByID(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error)
+	GetFailedDeliveries(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]WebhookDelivery, error)
 }
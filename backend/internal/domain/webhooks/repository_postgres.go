@@ -3,6 +3,7 @@ package webhooks
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -105,3 +106,41 @@ func (r *postgresRepository) GetDeliveryByID(ctx context.Context, id uuid.UUID)
 	}
 	return &delivery, nil
 }
+
+// GetFailedDeliveries returns the last failed delivery of each payload sent to the active
+// webhooks of a festival in [from, to), leaving out the payloads delivered since
+func (r *postgresRepository) GetFailedDeliveries(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (d.webhook_id, d.payload) d.*
+		FROM public.webhook_deliveries d
+		INNER JOIN public.webhooks w ON w.id = d.webhook_id
+		WHERE w.festival_id = ? AND w.is_active = true
+			AND d.success = false AND d.delivered_at >= ? AND d.delivered_at < ?
+			AND NOT EXISTS (
+				SELECT 1 FROM public.webhook_deliveries ok
+				WHERE ok.webhook_id = d.webhook_id AND ok.payload = d.payload AND ok.success = true
+			)
+		ORDER BY d.webhook_id, d.payload, d.delivered_at DESC`,
+		festivalID, from, to,
+	).Scan(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	var webhooks []Webhook
+	if err := r.db.WithContext(ctx).Where("festival_id = ?", festivalID).Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	byID := make(map[uuid.UUID]*Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+	for i := range deliveries {
+		deliveries[i].Webhook = byID[deliveries[i].WebhookID]
+	}
+	return deliveries, nil
+}
@@ -219,7 +219,7 @@ func (s *Service) signPayload(secret string, payload []byte) string {
 }
 
 // recordDelivery saves a webhook delivery record
-func (s *Service) recordDelivery(ctx context.Context, webhookID uuid.UUID, event, payload string, responseCode *int, responseBody *string, duration time.Duration, success bool, errMsg string) {
+func (s *Service) recordDelivery(ctx context.Context, webhookID uuid.UUID, event, payload string, responseCode *int, responseBody *string, duration time.Duration, success bool, errMsg string) *WebhookDelivery {
 	durationMs := int(duration.Milliseconds())
 	delivery := &WebhookDelivery{
 		ID:           uuid.New(),
@@ -240,6 +240,59 @@ func (s *Service) recordDelivery(ctx context.Context, webhookID uuid.UUID, event
 	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
 		log.Error().Err(err).Msg("Failed to record webhook delivery")
 	}
+	return delivery
+}
+
+// FailedDeliveries returns the payloads of a festival that failed to be delivered in
+// [from, to) and were not delivered since, with their webhook
+func (s *Service) FailedDeliveries(ctx context.Context, festivalID uuid.UUID, from, to time.Time) ([]WebhookDelivery, error) {
+	return s.repo.GetFailedDeliveries(ctx, festivalID, from, to)
+}
+
+// Redeliver sends the payload of a failed delivery again, signed with the current secret
+// of its webhook. The payload keeps its ID so receivers can tell a redelivery from a new
+// event. The new delivery is recorded and returned.
+func (s *Service) Redeliver(ctx context.Context, failed *WebhookDelivery) (*WebhookDelivery, error) {
+	webhook := failed.Webhook
+	if webhook == nil {
+		var err error
+		if webhook, err = s.repo.GetByID(ctx, failed.WebhookID); err != nil {
+			return nil, err
+		}
+		if webhook == nil {
+			return nil, errors.ErrNotFound
+		}
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal([]byte(failed.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("failed to read delivery payload: %w", err)
+	}
+
+	startTime := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader([]byte(failed.Payload)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Signature", s.signPayload(webhook.Secret, []byte(failed.Payload)))
+	req.Header.Set("X-Webhook-Event", failed.Event)
+	req.Header.Set("X-Webhook-ID", payload.ID)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return s.recordDelivery(ctx, webhook.ID, failed.Event, failed.Payload, nil, nil, time.Since(startTime), false, err.Error()), nil
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 10*1024))
+	bodyStr := string(bodyBytes)
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	var errMsg string
+	if !success {
+		errMsg = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return s.recordDelivery(ctx, webhook.ID, failed.Event, failed.Payload, &resp.StatusCode, &bodyStr, time.Since(startTime), success, errMsg), nil
 }
 
 // GetDeliveryLogs returns delivery logs for a webhook
@@ -0,0 +1,223 @@
+// Package backpressure sheds the non-critical requests of an instance while the
+// background queues or the database fall behind, so that the capacity left goes to
+// payments and orders.
+package backpressure
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/mimi6060/festivals/backend/internal/pkg/response"
+	"github.com/rs/zerolog/log"
+)
+
+// Reasons to shed load
+const (
+	ReasonQueueBacklog = "queue_backlog"
+	ReasonDBLatency    = "db_latency"
+)
+
+// QueueBacklog returns the tasks waiting in the background queues, satisfied by
+// queue.Inspector
+type QueueBacklog interface {
+	Backlog() (int, error)
+}
+
+// DBLatency returns a percentile of the recent statement durations with the number of
+// statements it was computed on, satisfied by database.LatencyPlugin
+type DBLatency interface {
+	Percentile(q float64) (time.Duration, int)
+}
+
+// Config configures the backpressure controller
+type Config struct {
+	Interval        time.Duration // Between two checks of the signals
+	MaxQueueBacklog int           // Above it load is shed; 0 ignores the queues
+	MaxDBP99        time.Duration // Above it load is shed; 0 ignores the database
+	MinDBSamples    int           // Statements needed for the p99 to count
+	RecoverRatio    float64       // Shedding stops once every signal is under this share of its limit
+	RetryAfter      time.Duration // Sent to the clients of the shed requests
+}
+
+// DefaultConfig returns the default backpressure configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:        5 * time.Second,
+		MaxQueueBacklog: 10000,
+		MaxDBP99:        500 * time.Millisecond,
+		MinDBSamples:    50,
+		RecoverRatio:    0.8,
+		RetryAfter:      30 * time.Second,
+	}
+}
+
+// State is the load shedding state of the instance and the signals it was decided on
+type State struct {
+	Shedding     bool          `json:"shedding"`
+	Reasons      []string      `json:"reasons,omitempty"`
+	Since        *time.Time    `json:"since,omitempty"` // Start of the shedding
+	QueueBacklog int           `json:"queueBacklog"`
+	DBP99        time.Duration `json:"dbP99"`
+	DBSamples    int           `json:"dbSamples"`
+	CheckedAt    time.Time     `json:"checkedAt"`
+}
+
+// Controller watches the queue backlog and the database p99, and sheds the requests
+// of the routes it guards while either is over its limit. Shedding starts as soon as a
+// signal crosses its limit and stops once all are back under RecoverRatio of it, so
+// the instance does not flap around a limit.
+type Controller struct {
+	config Config
+	queue  QueueBacklog
+	db     DBLatency
+	mu     sync.RWMutex
+	state  State
+	now    func() time.Time
+}
+
+// NewController creates a backpressure controller. A nil source is ignored.
+func NewController(config Config, queue QueueBacklog, db DBLatency) *Controller {
+	defaults := DefaultConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.MinDBSamples <= 0 {
+		config.MinDBSamples = defaults.MinDBSamples
+	}
+	if config.RecoverRatio <= 0 || config.RecoverRatio > 1 {
+		config.RecoverRatio = defaults.RecoverRatio
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = defaults.RetryAfter
+	}
+	return &Controller{config: config, queue: queue, db: db, now: time.Now}
+}
+
+// Start checks the signals every interval until ctx is done
+func (c *Controller) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads the signals and updates the shedding state
+func (c *Controller) Check() State {
+	next := State{CheckedAt: c.now()}
+
+	queueOver, queueRecovered := false, true
+	if c.queue != nil && c.config.MaxQueueBacklog > 0 {
+		backlog, err := c.queue.Backlog()
+		if err != nil {
+			// The queues cannot be read: the signal is left out rather than guessed
+			log.Warn().Err(err).Msg("Failed to read the queue backlog")
+		} else {
+			next.QueueBacklog = backlog
+			queueOver = backlog > c.config.MaxQueueBacklog
+			queueRecovered = float64(backlog) <= float64(c.config.MaxQueueBacklog)*c.config.RecoverRatio
+		}
+	}
+
+	dbOver, dbRecovered := false, true
+	if c.db != nil && c.config.MaxDBP99 > 0 {
+		next.DBP99, next.DBSamples = c.db.Percentile(0.99)
+		if next.DBSamples >= c.config.MinDBSamples {
+			dbOver = next.DBP99 > c.config.MaxDBP99
+			dbRecovered = float64(next.DBP99) <= float64(c.config.MaxDBP99)*c.config.RecoverRatio
+		}
+	}
+
+	c.mu.Lock()
+	previous := c.state
+	if previous.Shedding {
+		next.Shedding = !(queueRecovered && dbRecovered)
+	} else {
+		next.Shedding = queueOver || dbOver
+	}
+	if next.Shedding {
+		if queueOver || (previous.Shedding && !queueRecovered) {
+			next.Reasons = append(next.Reasons, ReasonQueueBacklog)
+		}
+		if dbOver || (previous.Shedding && !dbRecovered) {
+			next.Reasons = append(next.Reasons, ReasonDBLatency)
+		}
+		next.Since = previous.Since
+		if next.Since == nil {
+			since := next.CheckedAt
+			next.Since = &since
+		}
+	}
+	c.state = next
+	c.mu.Unlock()
+
+	switch {
+	case next.Shedding && !previous.Shedding:
+		log.Warn().
+			Strs("reasons", next.Reasons).
+			Int("queue_backlog", next.QueueBacklog).
+			Dur("db_p99", next.DBP99).
+			Msg("Shedding non-critical requests")
+	case !next.Shedding && previous.Shedding:
+		log.Info().
+			Int("queue_backlog", next.QueueBacklog).
+			Dur("db_p99", next.DBP99).
+			Dur("shed_for", next.CheckedAt.Sub(*previous.Since)).
+			Msg("Stopped shedding non-critical requests")
+	}
+	if m := monitoring.Get(); m != nil {
+		m.SetBackpressure(next.Shedding, next.QueueBacklog, next.DBP99.Seconds())
+	}
+
+	return next
+}
+
+// State returns the state of the last check
+func (c *Controller) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// Shed refuses the requests of the routes it guards while shedding, with 503
+// OVERLOADED and a Retry-After header. Only non-critical routes, such as feedback or
+// analytics, are to be guarded: payments and orders never are.
+func (c *Controller) Shed() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		state := c.State()
+		if !state.Shedding {
+			ctx.Next()
+			return
+		}
+
+		reason := ""
+		if len(state.Reasons) > 0 {
+			reason = state.Reasons[0]
+		}
+		if m := monitoring.Get(); m != nil {
+			m.RecordShedRequest(ctx.FullPath(), reason)
+		}
+
+		retryAfter := int(c.config.RetryAfter.Seconds())
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, response.ErrorResponse{
+			Error: response.ErrorDetail{
+				Code:    "OVERLOADED",
+				Message: "The service is under heavy load, please retry later",
+				Details: map[string]interface{}{"retry_after_seconds": retryAfter},
+			},
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package backpressure
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct {
+	backlog int
+	err     error
+}
+
+func (q *fakeQueue) Backlog() (int, error) {
+	return q.backlog, q.err
+}
+
+type fakeDB struct {
+	p99     time.Duration
+	samples int
+}
+
+func (d *fakeDB) Percentile(q float64) (time.Duration, int) {
+	return d.p99, d.samples
+}
+
+func newTestController(queue *fakeQueue, db *fakeDB) *Controller {
+	return NewController(Config{
+		MaxQueueBacklog: 1000,
+		MaxDBP99:        500 * time.Millisecond,
+		MinDBSamples:    10,
+		RecoverRatio:    0.8,
+		RetryAfter:      20 * time.Second,
+	}, queue, db)
+}
+
+func TestController_QueueBacklog(t *testing.T) {
+	queue := &fakeQueue{backlog: 200}
+	c := newTestController(queue, &fakeDB{})
+
+	assert.False(t, c.Check().Shedding)
+
+	queue.backlog = 1500
+	state := c.Check()
+	assert.True(t, state.Shedding)
+	assert.Equal(t, []string{ReasonQueueBacklog}, state.Reasons)
+	require.NotNil(t, state.Since)
+	since := *state.Since
+
+	// Under the limit but not recovered yet
+	queue.backlog = 900
+	state = c.Check()
+	assert.True(t, state.Shedding)
+	assert.Equal(t, since, *state.Since)
+
+	queue.backlog = 700
+	state = c.Check()
+	assert.False(t, state.Shedding)
+	assert.Nil(t, state.Since)
+}
+
+func TestController_DBLatency(t *testing.T) {
+	db := &fakeDB{p99: 2 * time.Second, samples: 3}
+	c := newTestController(&fakeQueue{}, db)
+
+	assert.False(t, c.Check().Shedding, "too few statements to count")
+
+	db.samples = 100
+	state := c.Check()
+	assert.True(t, state.Shedding)
+	assert.Equal(t, []string{ReasonDBLatency}, state.Reasons)
+
+	db.p99 = 300 * time.Millisecond
+	assert.False(t, c.Check().Shedding)
+}
+
+func TestController_QueueUnreadable(t *testing.T) {
+	c := newTestController(&fakeQueue{err: errors.New("redis down")}, &fakeDB{})
+
+	state := c.Check()
+	assert.False(t, state.Shedding)
+	assert.Zero(t, state.QueueBacklog)
+}
+
+func TestController_Shed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	queue := &fakeQueue{}
+	c := newTestController(queue, &fakeDB{})
+	c.Check()
+
+	router := gin.New()
+	router.POST("/feedback", c.Shed(), func(ctx *gin.Context) { ctx.Status(http.StatusCreated) })
+	router.POST("/orders", func(ctx *gin.Context) { ctx.Status(http.StatusCreated) })
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, serve("/feedback").Code)
+
+	queue.backlog = 5000
+	c.Check()
+
+	w := serve("/feedback")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "OVERLOADED")
+
+	// Routes not guarded go through
+	assert.Equal(t, http.StatusCreated, serve("/orders").Code)
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Redis subsystems. Each gets its own client so a burst in one (e.g. rate limiting on
+// every request) cannot exhaust the connection pool of the others.
+const (
+	SubsystemCache     = "cache"
+	SubsystemRateLimit = "ratelimit"
+	SubsystemSessions  = "sessions"
+	SubsystemRealtime  = "realtime"
+)
+
+// ClientOptions tunes the connection pool of a subsystem client. Zero values keep the
+// go-redis defaults.
+type ClientOptions struct {
+	PoolSize     int
+	MinIdleConns int
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+	Namespace    string // Prefix of the keys, e.g. the region; none when empty
+}
+
+// ConnectSubsystem connects a Redis client for a subsystem with its own pool and
+// records its command latencies under the subsystem label
+func ConnectSubsystem(redisURL, subsystem string, opts ClientOptions) (*redis.Client, error) {
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+
+	if opts.PoolSize > 0 {
+		opt.PoolSize = opts.PoolSize
+	}
+	if opts.MinIdleConns > 0 {
+		opt.MinIdleConns = opts.MinIdleConns
+	}
+	if opts.ReadTimeout != 0 {
+		opt.ReadTimeout = opts.ReadTimeout
+	}
+	if opts.WriteTimeout != 0 {
+		opt.WriteTimeout = opts.WriteTimeout
+	}
+	if opts.PoolTimeout != 0 {
+		opt.PoolTimeout = opts.PoolTimeout
+	}
+
+	client := redis.NewClient(opt)
+	client.AddHook(metricsHook{subsystem: subsystem})
+	SetNamespace(client, opts.Namespace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis (%s): %w", subsystem, err)
+	}
+
+	log.Info().
+		Str("subsystem", subsystem).
+		Int("pool_size", opt.PoolSize).
+		Dur("read_timeout", opt.ReadTimeout).
+		Str("namespace", opts.Namespace).
+		Msg("Connected to Redis")
+
+	return client, nil
+}
+
+// Clients holds one Redis client per subsystem
+type Clients struct {
+	Cache     *redis.Client
+	RateLimit *redis.Client
+	Sessions  *redis.Client
+	Realtime  *redis.Client
+}
+
+// ConnectClients connects a client for every subsystem. Subsystems missing from opts
+// use the go-redis defaults.
+func ConnectClients(redisURL string, opts map[string]ClientOptions) (*Clients, error) {
+	clients := &Clients{}
+	for _, target := range []struct {
+		subsystem string
+		client    **redis.Client
+	}{
+		{SubsystemCache, &clients.Cache},
+		{SubsystemRateLimit, &clients.RateLimit},
+		{SubsystemSessions, &clients.Sessions},
+		{SubsystemRealtime, &clients.Realtime},
+	} {
+		client, err := ConnectSubsystem(redisURL, target.subsystem, opts[target.subsystem])
+		if err != nil {
+			clients.Close()
+			return nil, err
+		}
+		*target.client = client
+	}
+	return clients, nil
+}
+
+// Close closes every connected client
+func (c *Clients) Close() error {
+	var errs []error
+	for _, client := range []*redis.Client{c.Cache, c.RateLimit, c.Sessions, c.Realtime} {
+		if client != nil {
+			errs = append(errs, client.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// metricsHook records command and pipeline latencies per subsystem
+type metricsHook struct {
+	subsystem string
+}
+
+func (h metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.record(cmd.Name(), err, time.Since(start))
+		return err
+	}
+}
+
+func (h metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.record("pipeline", err, time.Since(start))
+		return err
+	}
+}
+
+func (h metricsHook) record(command string, err error, duration time.Duration) {
+	m := monitoring.Get()
+	if m == nil {
+		return
+	}
+	failed := err != nil && !errors.Is(err, redis.Nil)
+	m.RecordRedisCommand(h.subsystem, command, failed, duration.Seconds())
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectClients(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	clients, err := ConnectClients("redis://"+server.Addr(), map[string]ClientOptions{
+		SubsystemRateLimit: {PoolSize: 50, MinIdleConns: 10, ReadTimeout: 100 * time.Millisecond, WriteTimeout: 100 * time.Millisecond},
+		SubsystemRealtime:  {PoolSize: 5, ReadTimeout: 3 * time.Second},
+		SubsystemSessions:  {Namespace: "eu-west"},
+	})
+	require.NoError(t, err)
+	defer clients.Close()
+
+	// One pool per subsystem
+	all := []*redis.Client{clients.Cache, clients.RateLimit, clients.Sessions, clients.Realtime}
+	for i, a := range all {
+		require.NotNil(t, a)
+		for _, b := range all[i+1:] {
+			assert.NotSame(t, a, b)
+		}
+	}
+
+	rateLimit := clients.RateLimit.Options()
+	assert.Equal(t, 50, rateLimit.PoolSize)
+	assert.Equal(t, 10, rateLimit.MinIdleConns)
+	assert.Equal(t, 100*time.Millisecond, rateLimit.ReadTimeout)
+	assert.Equal(t, 100*time.Millisecond, rateLimit.WriteTimeout)
+
+	realtime := clients.Realtime.Options()
+	assert.Equal(t, 5, realtime.PoolSize)
+	assert.Equal(t, 3*time.Second, realtime.ReadTimeout)
+	assert.Equal(t, 3*time.Second, realtime.WriteTimeout, "write timeout follows the read timeout")
+
+	// Subsystems without options fall back to the go-redis defaults
+	cache := clients.Cache.Options()
+	assert.Equal(t, 10*runtime.GOMAXPROCS(0), cache.PoolSize)
+	assert.Zero(t, cache.MinIdleConns)
+	assert.Equal(t, 3*time.Second, cache.ReadTimeout)
+
+	// Only the namespaced subsystem prefixes its keys
+	ctx := context.Background()
+	require.NoError(t, clients.Sessions.Set(ctx, "session:1", "alice", 0).Err())
+	require.NoError(t, clients.Cache.Set(ctx, "festival:1", "cached", 0).Err())
+	assert.True(t, server.Exists("eu-west:session:1"))
+	assert.True(t, server.Exists("festival:1"))
+
+	require.NoError(t, clients.Close())
+	assert.Error(t, clients.Cache.Ping(ctx).Err(), "closed")
+}
+
+func TestConnectClients_Unreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	clients, err := ConnectClients("redis://"+addr, nil)
+	assert.Nil(t, clients)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), SubsystemCache)
+
+	_, err = ConnectSubsystem("not-a-url", SubsystemRateLimit, ClientOptions{})
+	assert.ErrorContains(t, err, "failed to parse Redis URL")
+}
+
+func TestConnectSubsystem_RecordsLatency(t *testing.T) {
+	server := miniredis.RunT(t)
+	metrics := monitoring.Init("festivals")
+
+	client, err := ConnectSubsystem("redis://"+server.Addr(), SubsystemRateLimit, ClientOptions{})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	errorsBefore := testutil.ToFloat64(metrics.RedisCommandErrors.WithLabelValues(SubsystemRateLimit, "get"))
+	pipelinesBefore := observations(t, metrics.RedisCommandDuration.WithLabelValues(SubsystemRateLimit, "pipeline"))
+
+	// A missing key is not a failure
+	assert.ErrorIs(t, client.Get(ctx, "missing").Err(), redis.Nil)
+	pipe := client.Pipeline()
+	pipe.Incr(ctx, "counter")
+	pipe.Expire(ctx, "counter", time.Minute)
+	_, err = pipe.Exec(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, errorsBefore, testutil.ToFloat64(metrics.RedisCommandErrors.WithLabelValues(SubsystemRateLimit, "get")))
+	// A pipeline is timed once, not per command
+	assert.Equal(t, pipelinesBefore+1, observations(t, metrics.RedisCommandDuration.WithLabelValues(SubsystemRateLimit, "pipeline")))
+
+	// Commands failing on the server are counted per subsystem and command
+	server.SetError("LOADING")
+	assert.Error(t, client.Get(ctx, "counter").Err())
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metrics.RedisCommandErrors.WithLabelValues(SubsystemRateLimit, "get")))
+}
+
+// observations returns the number of samples of a histogram
+func observations(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Prefixes of the keys growing with the traffic, governed by memory budgets
+const (
+	PrefixSecurityAudit = "security:audit:"
+	PrefixAnalytics     = "analytics:"
+)
+
+const (
+	governanceReportKey = "governance:memory_report"
+	governanceLockKey   = "governance:sweep_lock"
+
+	// sweepBatch is the number of keys measured per round trip
+	sweepBatch = 200
+
+	// budgetRecovery is the share of its budget a prefix must fall under before its
+	// writers stop falling back, so that they do not flap around the budget
+	budgetRecovery = 0.9
+)
+
+// KeyBudget bounds the memory of the keys under a prefix
+type KeyBudget struct {
+	Prefix   string        // e.g. security:audit:
+	MaxBytes int64         // Above it the writers of the prefix sample or aggregate; 0 for no budget
+	MaxTTL   time.Duration // Keys without expiry, or expiring later, are given this TTL; 0 to leave them
+}
+
+// DefaultKeyBudgets returns the budgets of the security audit and analytics keys. Audit
+// keys are kept as long as the audit retention, analytics keys a week.
+func DefaultKeyBudgets(auditMaxBytes, analyticsMaxBytes int64) []KeyBudget {
+	return []KeyBudget{
+		{Prefix: PrefixSecurityAudit, MaxBytes: auditMaxBytes, MaxTTL: 90 * 24 * time.Hour},
+		{Prefix: PrefixAnalytics, MaxBytes: analyticsMaxBytes, MaxTTL: 7 * 24 * time.Hour},
+	}
+}
+
+// PrefixUsage is the memory used by the keys under a prefix at the last sweep
+type PrefixUsage struct {
+	Prefix      string `json:"prefix"`
+	Keys        int64  `json:"keys"`
+	Bytes       int64  `json:"bytes"`
+	BudgetBytes int64  `json:"budget_bytes,omitempty"`
+	OverBudget  bool   `json:"over_budget"`
+	ExpiryFixed int64  `json:"expiry_fixed"` // Keys given the maximum TTL by the sweep
+}
+
+// MemoryReport is the memory usage measured by a sweep
+type MemoryReport struct {
+	UsedMemory int64         `json:"used_memory"` // Whole Redis instance
+	MaxMemory  int64         `json:"max_memory,omitempty"`
+	Prefixes   []PrefixUsage `json:"prefixes"`
+	SweptAt    time.Time     `json:"swept_at"`
+	DurationMs int64         `json:"duration_ms"`
+}
+
+// MemoryGovernor measures the memory used per key prefix, enforces a maximum TTL on
+// the keys of each prefix and tells the writers of a prefix over its budget to fall
+// back to sampling or aggregation. Sweeps are shared: one process sweeps per interval
+// and stores the report, the others load it.
+type MemoryGovernor struct {
+	client   *redis.Client
+	budgets  []KeyBudget
+	interval time.Duration
+
+	mu     sync.RWMutex
+	report *MemoryReport
+}
+
+// NewMemoryGovernor creates a memory governor sweeping every interval
+func NewMemoryGovernor(client *redis.Client, interval time.Duration, budgets ...KeyBudget) *MemoryGovernor {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &MemoryGovernor{
+		client:   client,
+		budgets:  budgets,
+		interval: interval,
+	}
+}
+
+// Start sweeps, or loads the report of the process that swept, every interval until
+// the context is cancelled
+func (g *MemoryGovernor) Start(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Failed to refresh Redis memory usage")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (g *MemoryGovernor) refresh(ctx context.Context) error {
+	acquired, err := g.client.SetNX(ctx, governanceLockKey, 1, g.interval*9/10).Result()
+	if err != nil {
+		return fmt.Errorf("failed to acquire sweep lock: %w", err)
+	}
+	if acquired {
+		_, err := g.Sweep(ctx)
+		return err
+	}
+
+	data, err := g.client.Get(ctx, governanceReportKey).Bytes()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load memory report: %w", err)
+	}
+	var report MemoryReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return fmt.Errorf("failed to parse memory report: %w", err)
+	}
+	g.setReport(&report)
+	return nil
+}
+
+// Sweep measures the memory of every governed prefix, enforcing their maximum TTL,
+// and shares the report with the other processes
+func (g *MemoryGovernor) Sweep(ctx context.Context) (*MemoryReport, error) {
+	start := time.Now()
+	report := &MemoryReport{SweptAt: start}
+
+	info, err := g.client.Info(ctx, "memory").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory info: %w", err)
+	}
+	report.UsedMemory, report.MaxMemory = parseMemoryInfo(info)
+
+	for _, budget := range g.budgets {
+		usage, err := g.sweepPrefix(ctx, budget)
+		if err != nil {
+			return nil, err
+		}
+		usage.OverBudget = overBudget(usage.Bytes, budget.MaxBytes, g.OverBudget(budget.Prefix))
+		report.Prefixes = append(report.Prefixes, usage)
+
+		if usage.OverBudget {
+			log.Warn().
+				Str("prefix", usage.Prefix).
+				Int64("bytes", usage.Bytes).
+				Int64("budget", budget.MaxBytes).
+				Msg("Redis keys over their memory budget, writers falling back")
+		}
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	g.setReport(report)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal memory report: %w", err)
+	}
+	if err := g.client.Set(ctx, governanceReportKey, data, 3*g.interval).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store memory report: %w", err)
+	}
+	return report, nil
+}
+
+// sweepPrefix measures the keys under a prefix by batches, giving the maximum TTL to
+// the keys without expiry or expiring later
+func (g *MemoryGovernor) sweepPrefix(ctx context.Context, budget KeyBudget) (PrefixUsage, error) {
+	usage := PrefixUsage{Prefix: budget.Prefix, BudgetBytes: budget.MaxBytes}
+
+	iter := g.client.Scan(ctx, 0, budget.Prefix+"*", sweepBatch).Iterator()
+	batch := make([]string, 0, sweepBatch)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == sweepBatch {
+			if err := g.measure(ctx, budget, batch, &usage); err != nil {
+				return usage, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return usage, fmt.Errorf("failed to scan %s keys: %w", budget.Prefix, err)
+	}
+	if len(batch) > 0 {
+		if err := g.measure(ctx, budget, batch, &usage); err != nil {
+			return usage, err
+		}
+	}
+	return usage, nil
+}
+
+func (g *MemoryGovernor) measure(ctx context.Context, budget KeyBudget, keys []string, usage *PrefixUsage) error {
+	pipe := g.client.Pipeline()
+	sizes := make([]*redis.IntCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		sizes[i] = pipe.MemoryUsage(ctx, key)
+		ttls[i] = pipe.TTL(ctx, key)
+	}
+	// Keys expiring between SCAN and MEMORY USAGE fail with a nil reply
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to measure %s keys: %w", budget.Prefix, err)
+	}
+
+	expire := g.client.Pipeline()
+	for i, key := range keys {
+		size, err := sizes[i].Result()
+		if err != nil {
+			continue
+		}
+		usage.Keys++
+		usage.Bytes += size
+
+		if ttl := ttls[i].Val(); budget.MaxTTL > 0 && (ttl == -1 || ttl > budget.MaxTTL) {
+			expire.Expire(ctx, key, budget.MaxTTL)
+			usage.ExpiryFixed++
+		}
+	}
+	if expire.Len() > 0 {
+		if _, err := expire.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to expire %s keys: %w", budget.Prefix, err)
+		}
+	}
+	return nil
+}
+
+func (g *MemoryGovernor) setReport(report *MemoryReport) {
+	g.mu.Lock()
+	g.report = report
+	g.mu.Unlock()
+
+	if m := monitoring.Get(); m != nil {
+		for _, usage := range report.Prefixes {
+			m.SetRedisPrefixMemory(usage.Prefix, float64(usage.Bytes), usage.OverBudget)
+		}
+	}
+}
+
+// Report returns the last memory report, nil before the first sweep
+func (g *MemoryGovernor) Report() *MemoryReport {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.report == nil {
+		return nil
+	}
+	report := *g.report
+	report.Prefixes = append([]PrefixUsage(nil), g.report.Prefixes...)
+	return &report
+}
+
+// OverBudget tells whether the keys under a prefix were over their budget at the last
+// sweep, and have not fallen back well under it since
+func (g *MemoryGovernor) OverBudget(prefix string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.report == nil {
+		return false
+	}
+	for _, usage := range g.report.Prefixes {
+		if usage.Prefix == prefix {
+			return usage.OverBudget
+		}
+	}
+	return false
+}
+
+// overBudget tells whether a prefix is over its budget. Once over, it stays over until
+// it falls under budgetRecovery of its budget.
+func overBudget(bytes, budget int64, wasOver bool) bool {
+	if budget <= 0 {
+		return false
+	}
+	if wasOver {
+		return float64(bytes) > float64(budget)*budgetRecovery
+	}
+	return bytes > budget
+}
+
+// parseMemoryInfo reads used_memory and maxmemory from INFO memory
+func parseMemoryInfo(info string) (used, maxMemory int64) {
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "used_memory":
+			used, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory":
+			maxMemory, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return used, maxMemory
+}
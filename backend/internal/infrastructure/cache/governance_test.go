@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverBudget(t *testing.T) {
+	assert.False(t, overBudget(900, 1000, false))
+	assert.True(t, overBudget(1001, 1000, false))
+	assert.True(t, overBudget(950, 1000, true), "stays over until well under the budget")
+	assert.False(t, overBudget(899, 1000, true))
+	assert.False(t, overBudget(1<<40, 0, false), "no budget")
+}
+
+func TestParseMemoryInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\nmaxmemory:4294967296\r\nmaxmemory_policy:noeviction\r\n"
+	used, maxMemory := parseMemoryInfo(info)
+	assert.Equal(t, int64(1048576), used)
+	assert.Equal(t, int64(4294967296), maxMemory)
+}
+
+func TestMemoryGovernor_OverBudget(t *testing.T) {
+	g := NewMemoryGovernor(nil, 0, DefaultKeyBudgets(100, 100)...)
+	assert.False(t, g.OverBudget(PrefixSecurityAudit), "not measured yet")
+	assert.Nil(t, g.Report())
+
+	g.setReport(&MemoryReport{Prefixes: []PrefixUsage{
+		{Prefix: PrefixSecurityAudit, Bytes: 150, BudgetBytes: 100, OverBudget: true},
+		{Prefix: PrefixAnalytics, Bytes: 50, BudgetBytes: 100},
+	}})
+	assert.True(t, g.OverBudget(PrefixSecurityAudit))
+	assert.False(t, g.OverBudget(PrefixAnalytics))
+	assert.False(t, g.OverBudget("session:"))
+
+	report := g.Report()
+	report.Prefixes[0].OverBudget = false
+	assert.True(t, g.OverBudget(PrefixSecurityAudit), "report returned as a copy")
+}
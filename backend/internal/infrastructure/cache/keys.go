@@ -65,6 +65,30 @@ func (k *KeyBuilder) FestivalListKey(page, perPage int) string {
 	return k.base(PrefixFestival, "list", fmt.Sprintf("p%d-pp%d", page, perPage))
 }
 
+// FestivalConfigKey returns the cache key for a festival's public branding config,
+// looked up by ID, slug or custom domain
+func (k *KeyBuilder) FestivalConfigKey(lookup string) string {
+	return k.base(PrefixFestival, "config", lookup)
+}
+
+// FestivalPublicStatsKey returns the cache key for a festival's public stats, looked
+// up by ID or slug
+func (k *KeyBuilder) FestivalPublicStatsKey(lookup string) string {
+	return k.base(PrefixFestival, "public-stats", lookup)
+}
+
+// FestivalMapOccupancyKey returns the cache key for the occupancy overlay of a
+// festival's map
+func (k *KeyBuilder) FestivalMapOccupancyKey(id uuid.UUID) string {
+	return k.base(PrefixFestival, "map-occupancy", id.String())
+}
+
+// FestivalMapOccupancyRefreshKey returns the key held while the occupancy overlay of a
+// festival's map is fresh or being computed
+func (k *KeyBuilder) FestivalMapOccupancyRefreshKey(id uuid.UUID) string {
+	return k.base(PrefixFestival, "map-occupancy", id.String(), "refresh")
+}
+
 // FestivalPattern returns a pattern to match all festival keys
 func (k *KeyBuilder) FestivalPattern() string {
 	return k.base(PrefixFestival, "*")
@@ -153,6 +177,26 @@ func (k *KeyBuilder) StandFestivalPattern(festivalID uuid.UUID) string {
 	return k.base(PrefixStand, "*", festivalID.String(), "*")
 }
 
+// StandWaitTimesKey returns the cache key for the wait-time estimates of a festival's stands
+func (k *KeyBuilder) StandWaitTimesKey(festivalID uuid.UUID) string {
+	return k.base(PrefixStand, "festival", festivalID.String(), "wait_times")
+}
+
+// StandRecommendationsKey returns the cache key for the menu recommendations of a festival's stands
+func (k *KeyBuilder) StandRecommendationsKey(festivalID uuid.UUID) string {
+	return k.base(PrefixStand, "festival", festivalID.String(), "recommendations")
+}
+
+// StandETAModelsKey returns the cache key for the preparation time models of a festival's stands
+func (k *KeyBuilder) StandETAModelsKey(festivalID uuid.UUID) string {
+	return k.base(PrefixStand, "festival", festivalID.String(), "eta_models")
+}
+
+// StandWaitAlertKey returns the key used to throttle wait-time alerts for a stand
+func (k *KeyBuilder) StandWaitAlertKey(standID uuid.UUID, level string) string {
+	return k.base(PrefixStand, "id", standID.String(), "wait_alert", level)
+}
+
 // --- Product Keys ---
 
 // ProductKey returns the cache key for a product by ID
@@ -243,6 +287,11 @@ func (k *KeyBuilder) StatsFestivalPattern(festivalID uuid.UUID) string {
 	return k.base(PrefixStats, "*", festivalID.String(), "*")
 }
 
+// DeliveryCountersKey returns the key of the daily suppression check counters of a channel
+func (k *KeyBuilder) DeliveryCountersKey(channel, date string) string {
+	return k.base(PrefixStats, "deliveries", channel, date)
+}
+
 // --- Search Keys ---
 
 // SearchKey returns the cache key for a search query
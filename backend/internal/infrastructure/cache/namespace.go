@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPositions tells where the keys of a command are, for the commands whose keys are
+// not just their first argument
+type keyPositions int
+
+const (
+	keysFirst        keyPositions = iota // KEY ...
+	keysNone                             // No key, e.g. PING; pub/sub channels are not namespaced
+	keysAll                              // KEY [KEY ...]
+	keysPairs                            // KEY VALUE [KEY VALUE ...]
+	keysTwo                              // SOURCE DESTINATION ...
+	keysAllButLast                       // KEY [KEY ...] TIMEOUT
+	keysCounted                          // NUMKEYS KEY [KEY ...] ...
+	keysStoreCounted                     // DESTINATION NUMKEYS KEY [KEY ...] ...
+	keysScripted                         // SCRIPT NUMKEYS KEY [KEY ...] ARG ...
+	keysScan                             // CURSOR [MATCH PATTERN] ...
+	keysSubcommand                       // SUBCOMMAND [KEY], e.g. MEMORY USAGE KEY
+)
+
+var commandKeys = map[string]keyPositions{
+	"ping": keysNone, "echo": keysNone, "info": keysNone, "client": keysNone,
+	"script": keysNone, "flushdb": keysNone, "flushall": keysNone, "dbsize": keysNone,
+	"time": keysNone, "config": keysNone, "hello": keysNone, "auth": keysNone,
+	"select": keysNone, "command": keysNone, "publish": keysNone, "pubsub": keysNone,
+	"multi": keysNone, "exec": keysNone, "discard": keysNone, "unwatch": keysNone,
+	"quit": keysNone, "slowlog": keysNone, "wait": keysNone, "readonly": keysNone,
+	"readwrite": keysNone, "function": keysNone,
+
+	"del": keysAll, "unlink": keysAll, "exists": keysAll, "mget": keysAll,
+	"touch": keysAll, "watch": keysAll, "sinter": keysAll, "sunion": keysAll,
+	"sdiff": keysAll, "sinterstore": keysAll, "sunionstore": keysAll,
+	"sdiffstore": keysAll, "pfcount": keysAll, "pfmerge": keysAll,
+
+	"mset": keysPairs, "msetnx": keysPairs,
+
+	"rename": keysTwo, "renamenx": keysTwo, "rpoplpush": keysTwo, "brpoplpush": keysTwo,
+	"smove": keysTwo, "lmove": keysTwo, "blmove": keysTwo, "copy": keysTwo,
+
+	"blpop": keysAllButLast, "brpop": keysAllButLast, "bzpopmin": keysAllButLast,
+	"bzpopmax": keysAllButLast,
+
+	"zunion": keysCounted, "zinter": keysCounted, "zdiff": keysCounted,
+	"zunionstore": keysStoreCounted, "zinterstore": keysStoreCounted,
+	"zdiffstore": keysStoreCounted,
+
+	"eval": keysScripted, "evalsha": keysScripted, "eval_ro": keysScripted,
+	"evalsha_ro": keysScripted, "fcall": keysScripted, "fcall_ro": keysScripted,
+
+	"scan": keysScan,
+
+	"memory": keysSubcommand, "object": keysSubcommand,
+}
+
+// SetNamespace prefixes the keys of every command of the client with "<namespace>:",
+// e.g. the region. Every process sharing keys must use the same namespace.
+func SetNamespace(client *redis.Client, namespace string) {
+	if namespace != "" {
+		client.AddHook(newNamespaceHook(namespace))
+	}
+}
+
+// namespaceHook prefixes the keys of every command with the namespace, so that
+// regions sharing a Redis deployment never read or overwrite the keys of each other.
+// SCAN and KEYS only see the keys of the namespace and return them without the
+// prefix, so that callers can pass them back to other commands. Keys already carrying
+// the prefix are left as they are, as the SCAN iterator processes the same command
+// again for each page.
+type namespaceHook struct {
+	prefix string
+}
+
+// newNamespaceHook returns the hook namespacing keys under "<namespace>:"
+func newNamespaceHook(namespace string) namespaceHook {
+	return namespaceHook{prefix: namespace + ":"}
+}
+
+func (h namespaceHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h namespaceHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.prefixKeys(cmd)
+		err := next(ctx, cmd)
+		h.stripKeys(cmd)
+		return err
+	}
+}
+
+func (h namespaceHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.prefixKeys(cmd)
+		}
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			h.stripKeys(cmd)
+		}
+		return err
+	}
+}
+
+// prefixKeys prefixes the keys among the arguments of a command in place
+func (h namespaceHook) prefixKeys(cmd redis.Cmder) {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return
+	}
+
+	switch commandKeys[cmd.Name()] {
+	case keysNone:
+		return
+	case keysAll:
+		h.prefixArgs(args, 1, len(args), 1)
+	case keysPairs:
+		h.prefixArgs(args, 1, len(args), 2)
+	case keysTwo:
+		h.prefixArgs(args, 1, 3, 1)
+	case keysAllButLast:
+		h.prefixArgs(args, 1, len(args)-1, 1)
+	case keysCounted:
+		h.prefixCounted(args, 1)
+	case keysStoreCounted:
+		h.prefixArgs(args, 1, 2, 1)
+		h.prefixCounted(args, 2)
+	case keysScripted:
+		h.prefixCounted(args, 2)
+	case keysSubcommand:
+		h.prefixArgs(args, 2, 3, 1)
+	case keysScan:
+		for i := 1; i+1 < len(args); i++ {
+			if option, ok := args[i].(string); ok && strings.EqualFold(option, "match") {
+				h.prefixArgs(args, i+1, i+2, 1)
+			}
+		}
+	default:
+		h.prefixArgs(args, 1, 2, 1)
+	}
+}
+
+// prefixCounted prefixes the keys following their count at args[at]
+func (h namespaceHook) prefixCounted(args []interface{}, at int) {
+	if at >= len(args) {
+		return
+	}
+	var count int
+	switch n := args[at].(type) {
+	case int:
+		count = n
+	case int64:
+		count = int(n)
+	case string:
+		count, _ = strconv.Atoi(n)
+	}
+	h.prefixArgs(args, at+1, at+1+count, 1)
+}
+
+// prefixArgs prefixes the string arguments from start to end, every step
+func (h namespaceHook) prefixArgs(args []interface{}, start, end, step int) {
+	if end > len(args) {
+		end = len(args)
+	}
+	for i := start; i < end; i += step {
+		if key, ok := args[i].(string); ok && !strings.HasPrefix(key, h.prefix) {
+			args[i] = h.prefix + key
+		}
+	}
+}
+
+// stripKeys removes the prefix from the keys returned by SCAN and KEYS. A SCAN without
+// MATCH sees the whole database, so the keys of other namespaces are dropped.
+func (h namespaceHook) stripKeys(cmd redis.Cmder) {
+	switch cmd.Name() {
+	case "scan":
+		if scan, ok := cmd.(*redis.ScanCmd); ok {
+			keys, cursor := scan.Val()
+			scan.SetVal(h.strip(keys), cursor)
+		}
+	case "keys":
+		if list, ok := cmd.(*redis.StringSliceCmd); ok {
+			list.SetVal(h.strip(list.Val()))
+		}
+	}
+}
+
+func (h namespaceHook) strip(keys []string) []string {
+	stripped := keys[:0]
+	for _, key := range keys {
+		if strings.HasPrefix(key, h.prefix) {
+			stripped = append(stripped, strings.TrimPrefix(key, h.prefix))
+		}
+	}
+	return stripped
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceHook_PrefixKeys(t *testing.T) {
+	hook := newNamespaceHook("eu-west")
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		cmd  redis.Cmder
+		want []interface{}
+	}{
+		"first key": {
+			cmd:  redis.NewStringCmd(ctx, "set", "session:1", "value", "ex", 60),
+			want: []interface{}{"set", "eu-west:session:1", "value", "ex", 60},
+		},
+		"all keys": {
+			cmd:  redis.NewIntCmd(ctx, "del", "a", "b"),
+			want: []interface{}{"del", "eu-west:a", "eu-west:b"},
+		},
+		"pairs": {
+			cmd:  redis.NewStatusCmd(ctx, "mset", "a", "1", "b", "2"),
+			want: []interface{}{"mset", "eu-west:a", "1", "eu-west:b", "2"},
+		},
+		"script keys only": {
+			cmd:  redis.NewCmd(ctx, "evalsha", "sha", 2, "tokens", "last", "rate", 10),
+			want: []interface{}{"evalsha", "sha", 2, "eu-west:tokens", "eu-west:last", "rate", 10},
+		},
+		"stored union": {
+			cmd:  redis.NewIntCmd(ctx, "zunionstore", "dest", 2, "a", "b", "weights", "1", "2"),
+			want: []interface{}{"zunionstore", "eu-west:dest", 2, "eu-west:a", "eu-west:b", "weights", "1", "2"},
+		},
+		"scan pattern": {
+			cmd:  redis.NewScanCmd(ctx, nil, "scan", 0, "match", "session:*", "count", 100),
+			want: []interface{}{"scan", 0, "match", "eu-west:session:*", "count", 100},
+		},
+		"subcommand key": {
+			cmd:  redis.NewIntCmd(ctx, "memory", "usage", "security:audit:events"),
+			want: []interface{}{"memory", "usage", "eu-west:security:audit:events"},
+		},
+		"subcommand without key": {
+			cmd:  redis.NewMapStringIntCmd(ctx, "memory", "stats"),
+			want: []interface{}{"memory", "stats"},
+		},
+		"channels untouched": {
+			cmd:  redis.NewIntCmd(ctx, "publish", "geoaccess:reload", "id"),
+			want: []interface{}{"publish", "geoaccess:reload", "id"},
+		},
+		"already prefixed": {
+			cmd:  redis.NewStringCmd(ctx, "get", "eu-west:session:1"),
+			want: []interface{}{"get", "eu-west:session:1"},
+		},
+	}
+	for name, tc := range cases {
+		hook.prefixKeys(tc.cmd)
+		assert.Equal(t, tc.want, tc.cmd.Args(), name)
+	}
+}
+
+func TestNamespaceHook_StripKeys(t *testing.T) {
+	hook := newNamespaceHook("eu-west")
+	ctx := context.Background()
+
+	scan := redis.NewScanCmd(ctx, nil, "scan", 0)
+	scan.SetVal([]string{"eu-west:session:1", "us-east:session:2", "eu-west:session:3"}, 42)
+	hook.stripKeys(scan)
+
+	keys, cursor := scan.Val()
+	assert.Equal(t, []string{"session:1", "session:3"}, keys, "keys of other namespaces dropped")
+	assert.Equal(t, uint64(42), cursor)
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const latencyStartKey = "latency:start"
+
+// latencySamples is the number of statement durations kept, the oldest replaced first
+const latencySamples = 4096
+
+// latencySample is the duration of a statement and when it ended
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// LatencyPlugin is a GORM plugin keeping the durations of the recent statements, so
+// that their percentiles can be read without scraping the metrics
+type LatencyPlugin struct {
+	window  time.Duration
+	mu      sync.Mutex
+	samples []latencySample
+	next    int
+	now     func() time.Time
+}
+
+// NewLatencyPlugin creates the latency plugin, to install with db.Use. Percentiles are
+// computed over the statements that ended within the window.
+func NewLatencyPlugin(window time.Duration) *LatencyPlugin {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &LatencyPlugin{
+		window:  window,
+		samples: make([]latencySample, 0, latencySamples),
+		now:     time.Now,
+	}
+}
+
+// Name implements gorm.Plugin
+func (p *LatencyPlugin) Name() string {
+	return "latency"
+}
+
+// Initialize implements gorm.Plugin, timing every kind of statement
+func (p *LatencyPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("latency:before_create", p.before),
+		cb.Create().After("gorm:create").Register("latency:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("latency:before_query", p.before),
+		cb.Query().After("gorm:query").Register("latency:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("latency:before_update", p.before),
+		cb.Update().After("gorm:update").Register("latency:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("latency:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("latency:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("latency:before_row", p.before),
+		cb.Row().After("gorm:row").Register("latency:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("latency:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("latency:after_raw", p.after),
+	)
+}
+
+func (p *LatencyPlugin) before(db *gorm.DB) {
+	db.InstanceSet(latencyStartKey, time.Now())
+}
+
+func (p *LatencyPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(latencyStartKey)
+	if !ok {
+		return
+	}
+	start, _ := value.(time.Time)
+	p.Observe(time.Since(start))
+}
+
+// Observe records the duration of a statement ending now
+func (p *LatencyPlugin) Observe(duration time.Duration) {
+	sample := latencySample{at: p.now(), duration: duration}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.samples) < latencySamples {
+		p.samples = append(p.samples, sample)
+		return
+	}
+	p.samples[p.next] = sample
+	p.next = (p.next + 1) % latencySamples
+}
+
+// Percentile returns the q-th quantile, e.g. 0.99, of the durations of the statements
+// that ended within the window, with the number of statements it was computed on
+func (p *LatencyPlugin) Percentile(q float64) (time.Duration, int) {
+	since := p.now().Add(-p.window)
+
+	p.mu.Lock()
+	durations := make([]time.Duration, 0, len(p.samples))
+	for _, sample := range p.samples {
+		if sample.at.After(since) {
+			durations = append(durations, sample.duration)
+		}
+	}
+	p.mu.Unlock()
+
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	index := int(math.Ceil(q*float64(len(durations)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(durations) {
+		index = len(durations) - 1
+	}
+	return durations[index], len(durations)
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyPlugin_Percentile(t *testing.T) {
+	now := time.Date(2026, 7, 18, 21, 0, 0, 0, time.UTC)
+	p := NewLatencyPlugin(time.Minute)
+	p.now = func() time.Time { return now }
+
+	p99, count := p.Percentile(0.99)
+	assert.Zero(t, p99)
+	assert.Zero(t, count)
+
+	for i := 1; i <= 100; i++ {
+		p.Observe(time.Duration(i) * time.Millisecond)
+	}
+	p99, count = p.Percentile(0.99)
+	assert.Equal(t, 99*time.Millisecond, p99)
+	assert.Equal(t, 100, count)
+	p50, _ := p.Percentile(0.5)
+	assert.Equal(t, 50*time.Millisecond, p50)
+
+	// Statements out of the window are no longer counted
+	now = now.Add(2 * time.Minute)
+	p.Observe(5 * time.Millisecond)
+	p99, count = p.Percentile(0.99)
+	assert.Equal(t, 5*time.Millisecond, p99)
+	assert.Equal(t, 1, count)
+}
+
+func TestLatencyPlugin_KeepsRecentSamples(t *testing.T) {
+	p := NewLatencyPlugin(time.Hour)
+
+	for i := 0; i < latencySamples; i++ {
+		p.Observe(time.Second)
+	}
+	for i := 0; i < latencySamples; i++ {
+		p.Observe(time.Millisecond)
+	}
+
+	p99, count := p.Percentile(0.99)
+	assert.Equal(t, time.Millisecond, p99)
+	assert.Equal(t, latencySamples, count)
+}
@@ -0,0 +1,388 @@
+package database
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const slowQueryStartKey = "slow_query:start"
+
+// writeKeyword finds the data-modifying CTEs and the row locks in a SELECT
+var writeKeyword = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|FOR\s+(NO\s+KEY\s+)?(UPDATE|SHARE|KEY\s+SHARE))\b`)
+
+// explainContextKey marks the EXPLAIN statements run by the plugin, so they are not
+// recorded themselves
+type explainContextKey struct{}
+
+// errExplainRollback rolls back the transaction EXPLAIN ANALYZE ran in
+var errExplainRollback = errors.New("explain rollback")
+
+// SlowQueryConfig configures the slow query plugin
+type SlowQueryConfig struct {
+	Threshold      time.Duration // Statements running longer are recorded
+	ExplainRate    float64       // Share of the new worst runs of a SELECT captured with EXPLAIN ANALYZE
+	ExplainTimeout time.Duration // Statement timeout of the EXPLAIN ANALYZE runs
+	MaxStatements  int           // Distinct statements kept, the least costly evicted first
+}
+
+// DefaultSlowQueryConfig returns the default slow query configuration
+func DefaultSlowQueryConfig() SlowQueryConfig {
+	return SlowQueryConfig{
+		Threshold:      200 * time.Millisecond,
+		ExplainRate:    0.1,
+		ExplainTimeout: 5 * time.Second,
+		MaxStatements:  200,
+	}
+}
+
+// SlowQueryStat aggregates the slow runs of a statement. Only the SQL with its
+// placeholders is kept: the bound values may hold personal data.
+type SlowQueryStat struct {
+	Fingerprint string       `json:"fingerprint"`
+	Query       string       `json:"query"`
+	Operation   string       `json:"operation"`
+	Table       string       `json:"table,omitempty"`
+	Domain      string       `json:"domain"`
+	Caller      string       `json:"caller"`
+	Count       int64        `json:"count"`
+	TotalMs     float64      `json:"total_ms"`
+	MeanMs      float64      `json:"mean_ms"`
+	MaxMs       float64      `json:"max_ms"`
+	LastRows    int64        `json:"last_rows"`
+	FirstSeen   time.Time    `json:"first_seen"`
+	LastSeen    time.Time    `json:"last_seen"`
+	Plan        *ExplainPlan `json:"plan,omitempty"`
+	PlanError   string       `json:"plan_error,omitempty"`
+	PlannedAt   *time.Time   `json:"planned_at,omitempty"`
+
+	total time.Duration
+	max   time.Duration
+}
+
+// slowRun is a statement run above the threshold
+type slowRun struct {
+	sql       string
+	operation string
+	table     string
+	domain    string
+	caller    string
+	rows      int64
+	duration  time.Duration
+	at        time.Time
+}
+
+// SlowQueryPlugin is a GORM plugin recording the statements running above a threshold.
+// Slow runs are counted per domain in the metrics and aggregated per statement for the
+// diagnostics endpoint; a sample of the new worst runs of each SELECT is captured with
+// EXPLAIN ANALYZE, one at a time, in a transaction rolled back afterwards.
+type SlowQueryPlugin struct {
+	config     SlowQueryConfig
+	db         *gorm.DB
+	mu         sync.Mutex
+	stats      map[string]*SlowQueryStat
+	explaining atomic.Bool
+	random     func() float64
+}
+
+// NewSlowQueryPlugin creates the slow query plugin, to install with db.Use
+func NewSlowQueryPlugin(config SlowQueryConfig) *SlowQueryPlugin {
+	defaults := DefaultSlowQueryConfig()
+	if config.Threshold <= 0 {
+		config.Threshold = defaults.Threshold
+	}
+	if config.ExplainTimeout <= 0 {
+		config.ExplainTimeout = defaults.ExplainTimeout
+	}
+	if config.MaxStatements <= 0 {
+		config.MaxStatements = defaults.MaxStatements
+	}
+	return &SlowQueryPlugin{
+		config: config,
+		stats:  make(map[string]*SlowQueryStat),
+		random: rand.Float64,
+	}
+}
+
+// Name implements gorm.Plugin
+func (p *SlowQueryPlugin) Name() string {
+	return "slow_query"
+}
+
+// Initialize implements gorm.Plugin, timing every kind of statement
+func (p *SlowQueryPlugin) Initialize(db *gorm.DB) error {
+	p.db = db
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("slow_query:before_create", p.before),
+		cb.Create().After("gorm:create").Register("slow_query:after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register("slow_query:before_query", p.before),
+		cb.Query().After("gorm:query").Register("slow_query:after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register("slow_query:before_update", p.before),
+		cb.Update().After("gorm:update").Register("slow_query:after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register("slow_query:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("slow_query:after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register("slow_query:before_row", p.before),
+		cb.Row().After("gorm:row").Register("slow_query:after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register("slow_query:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("slow_query:after_raw", p.after("raw")),
+	)
+}
+
+func (p *SlowQueryPlugin) before(db *gorm.DB) {
+	db.InstanceSet(slowQueryStartKey, time.Now())
+}
+
+func (p *SlowQueryPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(slowQueryStartKey)
+		if !ok {
+			return
+		}
+		start, _ := value.(time.Time)
+		duration := time.Since(start)
+		if duration < p.config.Threshold {
+			return
+		}
+		if ctx := db.Statement.Context; ctx != nil && ctx.Value(explainContextKey{}) != nil {
+			return
+		}
+		sql := db.Statement.SQL.String()
+		if sql == "" {
+			return
+		}
+
+		caller, domain := callerOf()
+		run := slowRun{
+			sql:       sql,
+			operation: operation,
+			table:     db.Statement.Table,
+			domain:    domain,
+			caller:    caller,
+			rows:      db.RowsAffected,
+			duration:  duration,
+			at:        start,
+		}
+		if m := monitoring.Get(); m != nil {
+			m.RecordSlowQuery(domain, operation)
+		}
+		log.Warn().
+			Str("query", truncateQuery(sql, 200)).
+			Dur("duration", duration).
+			Int64("rows", run.rows).
+			Str("domain", domain).
+			Str("caller", caller).
+			Msg("Slow query detected")
+
+		if fingerprint, explain := p.record(run); explain && p.explaining.CompareAndSwap(false, true) {
+			vars := append([]interface{}(nil), db.Statement.Vars...)
+			go p.explain(fingerprint, db.Dialector.Explain(sql, vars...))
+		}
+	}
+}
+
+// record aggregates a slow run and tells whether it should be explained: a SELECT
+// not explained yet or running slower than ever, when sampled
+func (p *SlowQueryPlugin) record(run slowRun) (string, bool) {
+	fingerprint := fingerprintQuery(run.sql)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat, ok := p.stats[fingerprint]
+	if !ok {
+		if len(p.stats) >= p.config.MaxStatements {
+			p.evict()
+		}
+		stat = &SlowQueryStat{
+			Fingerprint: fingerprint,
+			Query:       run.sql,
+			Operation:   run.operation,
+			Table:       run.table,
+			FirstSeen:   run.at,
+		}
+		p.stats[fingerprint] = stat
+	}
+
+	worst := run.duration > stat.max
+	stat.Count++
+	stat.total += run.duration
+	if worst {
+		stat.max = run.duration
+	}
+	stat.Domain = run.domain
+	stat.Caller = run.caller
+	stat.LastRows = run.rows
+	stat.LastSeen = run.at
+
+	explain := isSelect(run.sql) && (stat.Plan == nil || worst) && p.random() < p.config.ExplainRate
+	return fingerprint, explain
+}
+
+// evict removes the statement with the least total time
+func (p *SlowQueryPlugin) evict() {
+	var victim *SlowQueryStat
+	for _, stat := range p.stats {
+		if victim == nil || stat.total < victim.total {
+			victim = stat
+		}
+	}
+	if victim != nil {
+		delete(p.stats, victim.Fingerprint)
+	}
+}
+
+// explain captures the plan of a statement, with its values inlined. EXPLAIN ANALYZE
+// executes the statement, so it runs under a statement timeout in a transaction that
+// is always rolled back.
+func (p *SlowQueryPlugin) explain(fingerprint, sql string) {
+	defer p.explaining.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), explainContextKey{}, true), p.config.ExplainTimeout+time.Second)
+	defer cancel()
+
+	var plan *ExplainPlan
+	err := p.db.Session(&gorm.Session{NewDB: true, Context: ctx}).Transaction(func(tx *gorm.DB) error {
+		timeout := fmt.Sprintf("SET LOCAL statement_timeout = %d", p.config.ExplainTimeout.Milliseconds())
+		if err := tx.Exec(timeout).Error; err != nil {
+			return fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+		var err error
+		if plan, err = NewQueryAnalyzer(tx).ExplainAnalyze(ctx, sql); err != nil {
+			return err
+		}
+		return errExplainRollback
+	})
+	if errors.Is(err, errExplainRollback) {
+		err = nil
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("fingerprint", fingerprint).Msg("Failed to capture the plan of a slow query")
+	}
+
+	p.setPlan(fingerprint, plan, err)
+}
+
+func (p *SlowQueryPlugin) setPlan(fingerprint string, plan *ExplainPlan, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat, ok := p.stats[fingerprint]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	stat.PlannedAt = &now
+	if err != nil {
+		stat.PlanError = err.Error()
+		return
+	}
+	// Keep the statement with its placeholders rather than the inlined values
+	plan.Query = stat.Query
+	stat.Plan = plan
+	stat.PlanError = ""
+}
+
+// SlowQueries returns the recorded statements, the most costly in total first,
+// optionally limited to a domain
+func (p *SlowQueryPlugin) SlowQueries(domain string) []SlowQueryStat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]SlowQueryStat, 0, len(p.stats))
+	for _, stat := range p.stats {
+		if domain != "" && stat.Domain != domain {
+			continue
+		}
+		snapshot := *stat
+		snapshot.TotalMs = milliseconds(stat.total)
+		snapshot.MaxMs = milliseconds(stat.max)
+		snapshot.MeanMs = milliseconds(stat.total / time.Duration(stat.Count))
+		stats = append(stats, snapshot)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].total > stats[j].total
+	})
+	return stats
+}
+
+// ResetSlowQueries clears the recorded statements, e.g. after deploying an index
+func (p *SlowQueryPlugin) ResetSlowQueries() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats = make(map[string]*SlowQueryStat)
+}
+
+// fingerprintQuery identifies a statement by its SQL with whitespace collapsed; the
+// values are placeholders, so runs with different values share a fingerprint
+func fingerprintQuery(sql string) string {
+	sum := sha1.Sum([]byte(strings.Join(strings.Fields(sql), " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isSelect tells whether a statement only reads, and so can be run again by EXPLAIN
+// ANALYZE: a SELECT without data-modifying CTE nor row lock
+func isSelect(sql string) bool {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH":
+		return !writeKeyword.MatchString(sql)
+	}
+	return false
+}
+
+// callerOf returns the first caller outside GORM and this package, and the domain
+// package it belongs to
+func callerOf() (string, string) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.File, "gorm.io/") && !strings.Contains(frame.Function, "/infrastructure/database.") {
+			function := frame.Function
+			if i := strings.LastIndex(function, "/"); i >= 0 {
+				function = function[i+1:]
+			}
+			return fmt.Sprintf("%s:%d", function, frame.Line), domainOf(frame.Function)
+		}
+		if !more {
+			return "", "other"
+		}
+	}
+}
+
+// domainOf returns the domain package of a function, e.g. wallet for
+// .../internal/domain/wallet.(*repository).GetByID, or other outside the domains
+func domainOf(function string) string {
+	const marker = "/internal/domain/"
+	i := strings.Index(function, marker)
+	if i < 0 {
+		return "other"
+	}
+	domain := function[i+len(marker):]
+	if end := strings.IndexAny(domain, "./"); end >= 0 {
+		domain = domain[:end]
+	}
+	return domain
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPlugin(maxStatements int) *SlowQueryPlugin {
+	p := NewSlowQueryPlugin(SlowQueryConfig{ExplainRate: 0.9, MaxStatements: maxStatements})
+	p.random = func() float64 { return 0.5 }
+	return p
+}
+
+func slow(sql, domain string, duration time.Duration) slowRun {
+	return slowRun{sql: sql, operation: "query", domain: domain, duration: duration, at: time.Now()}
+}
+
+func TestSlowQueryPlugin_Record(t *testing.T) {
+	p := newTestPlugin(10)
+
+	const query = `SELECT * FROM "wallets" WHERE user_id = $1`
+	fingerprint, explain := p.record(slow(query, "wallet", 300*time.Millisecond))
+	assert.True(t, explain, "first run of a SELECT is explained")
+
+	// Same statement, whitespace aside
+	again, explain := p.record(slow("SELECT *  FROM \"wallets\"\n WHERE user_id = $1", "wallet", 250*time.Millisecond))
+	assert.Equal(t, fingerprint, again)
+	assert.True(t, explain, "not explained yet")
+
+	p.setPlan(fingerprint, &ExplainPlan{Query: "inlined", ExecutionTime: 280}, nil)
+	_, explain = p.record(slow(query, "wallet", 200*time.Millisecond))
+	assert.False(t, explain, "explained and not slower than before")
+	_, explain = p.record(slow(query, "wallet", 400*time.Millisecond))
+	assert.True(t, explain, "new worst run")
+
+	stats := p.SlowQueries("")
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(4), stats[0].Count)
+	assert.Equal(t, 1150.0, stats[0].TotalMs)
+	assert.Equal(t, 400.0, stats[0].MaxMs)
+	assert.Equal(t, 287.5, stats[0].MeanMs)
+	require.NotNil(t, stats[0].Plan)
+	assert.Equal(t, query, stats[0].Plan.Query, "values are not kept")
+
+	_, explain = p.record(slow(`UPDATE "wallets" SET balance = $1`, "wallet", time.Second))
+	assert.False(t, explain, "writes are never run again")
+
+	p.random = func() float64 { return 0.99 }
+	_, explain = p.record(slow(`SELECT * FROM "tickets"`, "ticket", time.Second))
+	assert.False(t, explain, "not sampled")
+}
+
+func TestSlowQueryPlugin_Eviction(t *testing.T) {
+	p := newTestPlugin(2)
+
+	p.record(slow("SELECT 1", "wallet", 900*time.Millisecond))
+	p.record(slow("SELECT 2", "ticket", 300*time.Millisecond))
+	p.record(slow("SELECT 3", "ticket", 600*time.Millisecond))
+
+	stats := p.SlowQueries("")
+	require.Len(t, stats, 2)
+	assert.Equal(t, "SELECT 1", stats[0].Query, "most costly first")
+	assert.Equal(t, "SELECT 3", stats[1].Query, "least costly evicted")
+
+	ticket := p.SlowQueries("ticket")
+	require.Len(t, ticket, 1)
+	assert.Equal(t, "SELECT 3", ticket[0].Query)
+
+	p.ResetSlowQueries()
+	assert.Empty(t, p.SlowQueries(""))
+}
+
+func TestIsSelect(t *testing.T) {
+	assert.True(t, isSelect(`SELECT * FROM "orders" WHERE updated_at > $1`))
+	assert.True(t, isSelect(`WITH totals AS (SELECT 1) SELECT * FROM totals`))
+	assert.False(t, isSelect(`SELECT * FROM "wallets" WHERE id = $1 FOR UPDATE`))
+	assert.False(t, isSelect(`select * from wallets for no key update`))
+	assert.False(t, isSelect(`WITH moved AS (DELETE FROM carts RETURNING *) SELECT * FROM moved`))
+	assert.False(t, isSelect(`INSERT INTO "orders" (id) VALUES ($1)`))
+	assert.False(t, isSelect(""))
+}
+
+func TestDomainOf(t *testing.T) {
+	assert.Equal(t, "wallet", domainOf("github.com/mimi6060/festivals/backend/internal/domain/wallet.(*repository).GetByID"))
+	assert.Equal(t, "banktransfer", domainOf("github.com/mimi6060/festivals/backend/internal/domain/banktransfer.(*Service).match.func1"))
+	assert.Equal(t, "other", domainOf("github.com/mimi6060/festivals/backend/internal/infrastructure/monitoring.(*HealthChecker).Check"))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -198,7 +199,7 @@ func GinLogger(logger *Logger) gin.HandlerFunc {
 		reqLogger := logger.WithRequestID(requestID).WithFields(map[string]interface{}{
 			"method": c.Request.Method,
 			"path":   c.Request.URL.Path,
-			"ip":     c.ClientIP(),
+			"ip":     privacy.LogIP(c.ClientIP()),
 		})
 
 		// Store logger in context
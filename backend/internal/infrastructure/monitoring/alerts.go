@@ -362,7 +362,11 @@ func (bam *BusinessAlertManager) sendWebhook(ctx context.Context, alert *Alert)
 	if bam.webhookURL == "" {
 		return
 	}
+	_ = postAlert(ctx, bam.webhookURL, alert)
+}
 
+// postAlert posts an alert to a webhook in the Alertmanager format
+func postAlert(ctx context.Context, webhookURL string, alert *Alert) error {
 	payload := map[string]interface{}{
 		"version":  "4",
 		"status":   string(alert.State),
@@ -380,12 +384,12 @@ func (bam *BusinessAlertManager) sendWebhook(ctx context.Context, alert *Alert)
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return
+		return fmt.Errorf("failed to encode alert: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", bam.webhookURL, strings.NewReader(string(body)))
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, strings.NewReader(string(body)))
 	if err != nil {
-		return
+		return fmt.Errorf("failed to create alert request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -393,9 +397,37 @@ func (bam *BusinessAlertManager) sendWebhook(ctx context.Context, alert *Alert)
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return
+		return fmt.Errorf("failed to send alert: %w", err)
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookAlerter fires one-off alerts raised outside the evaluated rules, e.g. by
+// batch jobs, to an Alertmanager-compatible webhook
+type WebhookAlerter struct {
+	webhookURL string
+}
+
+// NewWebhookAlerter creates an alerter posting to webhookURL. Alerts are dropped
+// when the URL is empty.
+func NewWebhookAlerter(webhookURL string) *WebhookAlerter {
+	return &WebhookAlerter{webhookURL: webhookURL}
+}
+
+// Fire sends the alert
+func (a *WebhookAlerter) Fire(ctx context.Context, alert *Alert) error {
+	if a.webhookURL == "" {
+		return nil
+	}
+	if alert.FiredAt.IsZero() {
+		alert.FiredAt = time.Now()
+	}
+	return postAlert(ctx, a.webhookURL, alert)
 }
 
 // GetActiveAlerts returns all active alerts
@@ -651,11 +683,21 @@ type SLODefinition struct {
 	Window          string  `json:"window"`          // e.g., "30d"
 	BurnRateThreshold float64 `json:"burnRateThreshold"` // e.g., 14.4 for 7-day budget burn
 	Indicator       string  `json:"indicator"`       // SLI metric name
+
+	// Endpoint SLOs are computed in-process from the requests of one route
+	Method           string        `json:"method,omitempty"`           // Empty means all methods
+	Path             string        `json:"path,omitempty"`             // Gin route, e.g. "/api/v1/orders/:id/pay"
+	LatencyThreshold time.Duration `json:"latencyThreshold,omitempty"` // Slower requests count as bad, 0 for availability only
 }
 
 // SLOTracker tracks SLO compliance and error budget
 type SLOTracker struct {
 	definitions map[string]*SLODefinition
+	endpoints   map[string]*endpointSLO
+	routes      map[string][]*endpointSLO // By "METHOD path", empty method for all methods
+	alerts      []BurnRateAlert
+	minRequests uint64
+	now         func() time.Time
 	mu          sync.RWMutex
 	registry    *prometheus.Registry
 	errorBudget *prometheus.GaugeVec
@@ -666,6 +708,10 @@ type SLOTracker struct {
 func NewSLOTracker(registry *prometheus.Registry) *SLOTracker {
 	tracker := &SLOTracker{
 		definitions: make(map[string]*SLODefinition),
+		endpoints:   make(map[string]*endpointSLO),
+		routes:      make(map[string][]*endpointSLO),
+		alerts:      DefaultBurnRateAlerts(),
+		now:         time.Now,
 		registry:    registry,
 	}
 
@@ -684,17 +730,29 @@ func NewSLOTracker(registry *prometheus.Registry) *SLOTracker {
 			Name:      "slo_burn_rate",
 			Help:      "Current error budget burn rate",
 		},
-		[]string{"slo_name"},
+		[]string{"slo_name", "window"},
 	)
 
 	return tracker
 }
 
-// RegisterSLO registers a new SLO definition
+// RegisterSLO registers a new SLO definition. SLOs with a path are computed from the
+// requests recorded for that route.
 func (st *SLOTracker) RegisterSLO(slo *SLODefinition) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
 	st.definitions[slo.Name] = slo
+
+	if slo.Path == "" {
+		return
+	}
+	if old, ok := st.endpoints[slo.Name]; ok {
+		st.removeRoute(old)
+	}
+	endpoint := newEndpointSLO(slo, st.alerts)
+	st.endpoints[slo.Name] = endpoint
+	key := routeKey(slo.Method, slo.Path)
+	st.routes[key] = append(st.routes[key], endpoint)
 }
 
 // UpdateErrorBudget updates the error budget for an SLO
@@ -711,31 +769,24 @@ func (st *SLOTracker) UpdateErrorBudget(name string, remaining float64) {
 // UpdateBurnRate updates the burn rate for an SLO
 func (st *SLOTracker) UpdateBurnRate(name string, rate float64) {
 	st.mu.RLock()
-	_, exists := st.definitions[name]
+	slo, exists := st.definitions[name]
 	st.mu.RUnlock()
 
 	if exists {
-		st.burnRate.WithLabelValues(name).Set(rate)
+		st.burnRate.WithLabelValues(name, slo.Window).Set(rate)
 	}
 }
 
 // GetSLOStatus returns the current status of an SLO
 func (st *SLOTracker) GetSLOStatus(name string) map[string]interface{} {
 	st.mu.RLock()
-	slo, exists := st.definitions[name]
-	st.mu.RUnlock()
+	defer st.mu.RUnlock()
 
+	slo, exists := st.definitions[name]
 	if !exists {
 		return nil
 	}
-
-	return map[string]interface{}{
-		"name":              slo.Name,
-		"description":       slo.Description,
-		"target":            slo.Target,
-		"window":            slo.Window,
-		"burnRateThreshold": slo.BurnRateThreshold,
-	}
+	return st.describe(slo)
 }
 
 // GetAllSLOs returns all SLO definitions and their status
@@ -745,17 +796,37 @@ func (st *SLOTracker) GetAllSLOs() []map[string]interface{} {
 
 	result := make([]map[string]interface{}, 0, len(st.definitions))
 	for _, slo := range st.definitions {
-		result = append(result, map[string]interface{}{
-			"name":              slo.Name,
-			"description":       slo.Description,
-			"target":            slo.Target,
-			"window":            slo.Window,
-			"burnRateThreshold": slo.BurnRateThreshold,
-		})
+		result = append(result, st.describe(slo))
 	}
 	return result
 }
 
+// describe returns an SLO definition with the rolling status of endpoint SLOs. The
+// caller must hold st.mu.
+func (st *SLOTracker) describe(slo *SLODefinition) map[string]interface{} {
+	status := map[string]interface{}{
+		"name":              slo.Name,
+		"description":       slo.Description,
+		"target":            slo.Target,
+		"window":            slo.Window,
+		"burnRateThreshold": slo.BurnRateThreshold,
+	}
+
+	if endpoint, ok := st.endpoints[slo.Name]; ok {
+		s := endpoint.status(st.now())
+		status["method"] = slo.Method
+		status["path"] = slo.Path
+		if slo.LatencyThreshold > 0 {
+			status["latencyThreshold"] = slo.LatencyThreshold.String()
+		}
+		status["goodRequests"] = s.Good
+		status["totalRequests"] = s.Total
+		status["errorBudgetRemaining"] = s.ErrorBudgetRemaining
+		status["burnRates"] = s.BurnRates
+	}
+	return status
+}
+
 // CreateDefaultSLOs creates default SLO definitions
 func CreateDefaultSLOs(tracker *SLOTracker) {
 	// API Availability SLO
@@ -22,6 +22,7 @@ type Metrics struct {
 	DBConnectionsIdle prometheus.Gauge
 	DBConnectionsUsed prometheus.Gauge
 	DBErrors          *prometheus.CounterVec
+	DBSlowQueries     *prometheus.CounterVec
 
 	// Cache metrics
 	CacheHitsTotal    *prometheus.CounterVec
@@ -30,6 +31,18 @@ type Metrics struct {
 	CacheOperations   *prometheus.CounterVec
 	CacheLatency      *prometheus.HistogramVec
 
+	// Redis metrics, labelled by the subsystem owning the client
+	RedisCommandDuration *prometheus.HistogramVec
+	RedisCommandErrors   *prometheus.CounterVec
+	RedisPrefixMemory    *prometheus.GaugeVec
+	RedisPrefixOver      *prometheus.GaugeVec
+
+	// Load shedding metrics
+	BackpressureActive       prometheus.Gauge
+	BackpressureQueueBacklog prometheus.Gauge
+	BackpressureDBP99        prometheus.Gauge
+	BackpressureShed         *prometheus.CounterVec
+
 	// Business metrics
 	TransactionsTotal   *prometheus.CounterVec
 	TransactionAmount   *prometheus.HistogramVec
@@ -40,12 +53,20 @@ type Metrics struct {
 	ActiveFestivals     prometheus.Gauge
 	FestivalAttendees   *prometheus.GaugeVec
 
+	// Notification delivery metrics
+	DeliveriesChecked    *prometheus.CounterVec
+	DeliveriesSuppressed *prometheus.CounterVec
+	SuppressionsAdded    *prometheus.CounterVec
+
 	// Error metrics
 	ErrorsTotal *prometheus.CounterVec
 
 	// Custom registry
 	Registry *prometheus.Registry
 
+	// Endpoint SLOs fed from the HTTP metrics
+	slo *SLOTracker
+
 	// Internal counters for hit ratio calculation
 	hitCounts  map[string]float64
 	missCounts map[string]float64
@@ -161,6 +182,15 @@ func NewMetrics(namespace string) *Metrics {
 			[]string{"operation", "error_type"},
 		),
 
+		DBSlowQueries: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "db_slow_queries_total",
+				Help:      "Total number of database statements above the slow query threshold by domain",
+			},
+			[]string{"domain", "operation"},
+		),
+
 		// Cache metrics
 		CacheHitsTotal: promauto.With(registry).NewCounterVec(
 			prometheus.CounterOpts{
@@ -208,6 +238,78 @@ func NewMetrics(namespace string) *Metrics {
 			[]string{"cache_name", "operation"},
 		),
 
+		// Redis metrics
+		RedisCommandDuration: promauto.With(registry).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "redis_command_duration_seconds",
+				Help:      "Duration of Redis commands and pipelines in seconds by subsystem",
+				Buckets:   []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+			},
+			[]string{"subsystem", "command"},
+		),
+
+		RedisCommandErrors: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "redis_command_errors_total",
+				Help:      "Total number of failed Redis commands and pipelines by subsystem",
+			},
+			[]string{"subsystem", "command"},
+		),
+
+		RedisPrefixMemory: promauto.With(registry).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "redis_prefix_memory_bytes",
+				Help:      "Memory used by the Redis keys under a governed prefix at the last sweep",
+			},
+			[]string{"prefix"},
+		),
+
+		RedisPrefixOver: promauto.With(registry).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "redis_prefix_over_budget",
+				Help:      "Whether the Redis keys under a governed prefix are over their memory budget (1) or not (0)",
+			},
+			[]string{"prefix"},
+		),
+
+		// Load shedding metrics
+		BackpressureActive: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "backpressure_active",
+				Help:      "Whether the instance sheds the non-critical requests (1) or not (0)",
+			},
+		),
+
+		BackpressureQueueBacklog: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "backpressure_queue_backlog",
+				Help:      "Background tasks waiting in the queues at the last backpressure check",
+			},
+		),
+
+		BackpressureDBP99: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "backpressure_db_p99_seconds",
+				Help:      "99th percentile of the database statement durations at the last backpressure check",
+			},
+		),
+
+		BackpressureShed: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "backpressure_shed_requests_total",
+				Help:      "Total number of non-critical requests refused while shedding load",
+			},
+			[]string{"path", "reason"},
+		),
+
 		// Business metrics
 		TransactionsTotal: promauto.With(registry).NewCounterVec(
 			prometheus.CounterOpts{
@@ -279,6 +381,34 @@ func NewMetrics(namespace string) *Metrics {
 			[]string{"festival_id"},
 		),
 
+		// Notification delivery metrics
+		DeliveriesChecked: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "notification_deliveries_checked_total",
+				Help:      "Total number of email/SMS deliveries checked against the suppression list",
+			},
+			[]string{"channel"},
+		),
+
+		DeliveriesSuppressed: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "notification_deliveries_suppressed_total",
+				Help:      "Total number of email/SMS deliveries skipped because the address is suppressed",
+			},
+			[]string{"channel", "reason"},
+		),
+
+		SuppressionsAdded: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "notification_suppressions_added_total",
+				Help:      "Total number of addresses added to the suppression list",
+			},
+			[]string{"channel", "reason", "source"},
+		),
+
 		// Error metrics
 		ErrorsTotal: promauto.With(registry).NewCounterVec(
 			prometheus.CounterOpts{
@@ -311,6 +441,15 @@ func (m *Metrics) RecordHTTPRequest(method, path, status string, duration float6
 	m.HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
 	m.HTTPRequestDuration.WithLabelValues(method, path).Observe(duration)
 	m.HTTPResponseSize.WithLabelValues(method, path).Observe(float64(responseSize))
+	if m.slo != nil {
+		m.slo.Record(method, path, status, duration)
+	}
+}
+
+// SetSLOTracker counts the recorded HTTP requests against the endpoint SLOs. It must
+// be called before requests are served.
+func (m *Metrics) SetSLOTracker(tracker *SLOTracker) {
+	m.slo = tracker
 }
 
 // IncrementInFlight increments the in-flight requests gauge
@@ -334,6 +473,11 @@ func (m *Metrics) RecordDBError(operation, errorType string) {
 	m.DBErrors.WithLabelValues(operation, errorType).Inc()
 }
 
+// RecordSlowQuery records a statement above the slow query threshold
+func (m *Metrics) RecordSlowQuery(domain, operation string) {
+	m.DBSlowQueries.WithLabelValues(domain, operation).Inc()
+}
+
 // SetDBConnections sets the current database connection pool stats
 func (m *Metrics) SetDBConnections(open, idle, inUse float64) {
 	m.DBConnectionsOpen.Set(open)
@@ -379,6 +523,40 @@ func (m *Metrics) RecordCacheOperation(cacheName, operation, status string, dura
 	m.CacheLatency.WithLabelValues(cacheName, operation).Observe(duration)
 }
 
+// RecordRedisCommand records the latency of a Redis command or pipeline
+func (m *Metrics) RecordRedisCommand(subsystem, command string, failed bool, duration float64) {
+	m.RedisCommandDuration.WithLabelValues(subsystem, command).Observe(duration)
+	if failed {
+		m.RedisCommandErrors.WithLabelValues(subsystem, command).Inc()
+	}
+}
+
+// SetRedisPrefixMemory sets the memory used by the keys under a prefix
+func (m *Metrics) SetRedisPrefixMemory(prefix string, bytes float64, overBudget bool) {
+	m.RedisPrefixMemory.WithLabelValues(prefix).Set(bytes)
+	over := 0.0
+	if overBudget {
+		over = 1
+	}
+	m.RedisPrefixOver.WithLabelValues(prefix).Set(over)
+}
+
+// SetBackpressure sets the load shedding state and the signals it was decided on
+func (m *Metrics) SetBackpressure(active bool, queueBacklog int, dbP99 float64) {
+	value := 0.0
+	if active {
+		value = 1
+	}
+	m.BackpressureActive.Set(value)
+	m.BackpressureQueueBacklog.Set(float64(queueBacklog))
+	m.BackpressureDBP99.Set(dbP99)
+}
+
+// RecordShedRequest records a non-critical request refused while shedding load
+func (m *Metrics) RecordShedRequest(path, reason string) {
+	m.BackpressureShed.WithLabelValues(path, reason).Inc()
+}
+
 // GetCacheHitRatio returns the current hit ratio for a cache
 func (m *Metrics) GetCacheHitRatio(cacheName string) float64 {
 	m.mu.RLock()
@@ -430,6 +608,19 @@ func (m *Metrics) SetFestivalAttendees(festivalID string, count float64) {
 	m.FestivalAttendees.WithLabelValues(festivalID).Set(count)
 }
 
+// RecordDeliveryCheck records a delivery checked against the suppression list
+func (m *Metrics) RecordDeliveryCheck(channel string, suppressed bool, reason string) {
+	m.DeliveriesChecked.WithLabelValues(channel).Inc()
+	if suppressed {
+		m.DeliveriesSuppressed.WithLabelValues(channel, reason).Inc()
+	}
+}
+
+// RecordSuppressionAdded records an address added to the suppression list
+func (m *Metrics) RecordSuppressionAdded(channel, reason, source string) {
+	m.SuppressionsAdded.WithLabelValues(channel, reason, source).Inc()
+}
+
 // RecordError records an error
 func (m *Metrics) RecordError(errorType, operation string) {
 	m.ErrorsTotal.WithLabelValues(errorType, operation).Inc()
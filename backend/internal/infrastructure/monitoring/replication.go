@@ -0,0 +1,118 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReplicationStatus is the state of the streaming replication between the primary
+// database and its warm standby
+type ReplicationStatus struct {
+	InRecovery bool          // The database is a standby replaying the WAL of the primary
+	Lag        time.Duration // On a standby, how far replay is behind the primary; on the primary, the slowest standby
+	Standbys   int           // On the primary, the standbys streaming from it
+}
+
+// ReplicationChecker checks the replication lag of a primary or standby database
+type ReplicationChecker struct {
+	db     *gorm.DB
+	maxLag time.Duration
+}
+
+// NewReplicationChecker creates a new ReplicationChecker
+func NewReplicationChecker(db *gorm.DB) *ReplicationChecker {
+	return &ReplicationChecker{
+		db:     db,
+		maxLag: 30 * time.Second,
+	}
+}
+
+// WithMaxLag sets the lag above which replication is degraded
+func (r *ReplicationChecker) WithMaxLag(lag time.Duration) *ReplicationChecker {
+	r.maxLag = lag
+	return r
+}
+
+// MaxLag returns the lag above which replication is degraded
+func (r *ReplicationChecker) MaxLag() time.Duration {
+	return r.maxLag
+}
+
+// Name returns the checker name
+func (r *ReplicationChecker) Name() string {
+	return "replication"
+}
+
+// Status queries the replication state of the database. A standby that has replayed
+// everything it received has no lag, even when the primary has been idle for a while.
+func (r *ReplicationChecker) Status(ctx context.Context) (ReplicationStatus, error) {
+	var status ReplicationStatus
+	db := r.db.WithContext(ctx)
+
+	if err := db.Raw("SELECT pg_is_in_recovery()").Scan(&status.InRecovery).Error; err != nil {
+		return status, fmt.Errorf("failed to check recovery: %w", err)
+	}
+
+	var row struct {
+		Standbys   int
+		LagSeconds float64
+	}
+	if status.InRecovery {
+		err := db.Raw(`SELECT 0 AS standbys, CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END AS lag_seconds`).Scan(&row).Error
+		if err != nil {
+			return status, fmt.Errorf("failed to get replay lag: %w", err)
+		}
+	} else {
+		err := db.Raw(`SELECT count(*) AS standbys, COALESCE(EXTRACT(EPOCH FROM max(replay_lag)), 0) AS lag_seconds
+			FROM pg_stat_replication`).Scan(&row).Error
+		if err != nil {
+			return status, fmt.Errorf("failed to get standbys: %w", err)
+		}
+	}
+
+	status.Standbys = row.Standbys
+	status.Lag = time.Duration(row.LagSeconds * float64(time.Second))
+	return status, nil
+}
+
+// Check performs the replication health check. Replication problems degrade the
+// service rather than make it unhealthy: the database itself is checked separately.
+func (r *ReplicationChecker) Check(ctx context.Context) ComponentHealth {
+	start := time.Now()
+	health := ComponentHealth{
+		Name:      r.Name(),
+		Status:    StatusHealthy,
+		Timestamp: time.Now().UTC(),
+		Details:   make(map[string]any),
+	}
+
+	status, err := r.Status(ctx)
+	health.Latency = time.Since(start)
+	if err != nil {
+		health.Status = StatusDegraded
+		health.Message = fmt.Sprintf("replication check failed: %v", err)
+		return health
+	}
+
+	health.Details["in_recovery"] = status.InRecovery
+	health.Details["lag_ms"] = status.Lag.Milliseconds()
+	if !status.InRecovery {
+		health.Details["standbys"] = status.Standbys
+	}
+
+	if status.Lag > r.maxLag {
+		health.Status = StatusDegraded
+		health.Message = fmt.Sprintf("replication lag of %s", status.Lag.Round(time.Second))
+	} else if !status.InRecovery && status.Standbys == 0 {
+		health.Status = StatusDegraded
+		health.Message = "no standby streaming from the primary"
+	}
+
+	return health
+}
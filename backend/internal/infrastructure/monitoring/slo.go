@@ -0,0 +1,440 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultSLOWindow is the error budget window of SLOs whose window cannot be parsed
+	defaultSLOWindow = 30 * 24 * time.Hour
+
+	// fineBucketWidth is the resolution of the counters behind the burn-rate windows
+	fineBucketWidth = 10 * time.Second
+
+	// budgetBuckets is the number of buckets the error budget window is split into
+	budgetBuckets = 720
+)
+
+// BurnRateAlert fires when an SLO burns its error budget faster than BurnRate times
+// the sustainable rate over both windows. The long window makes the alert significant,
+// the short one resolves it quickly once the burn stops.
+type BurnRateAlert struct {
+	Name        string        `yaml:"name" json:"name"`
+	Severity    AlertSeverity `yaml:"severity" json:"severity"`
+	LongWindow  time.Duration `yaml:"long_window" json:"longWindow"`
+	ShortWindow time.Duration `yaml:"short_window" json:"shortWindow"`
+	BurnRate    float64       `yaml:"burn_rate" json:"burnRate"`
+}
+
+// DefaultBurnRateAlerts returns the multiwindow alerts recommended for a 30 day
+// window: 2% of the budget spent in an hour pages, 5% in six hours warns
+func DefaultBurnRateAlerts() []BurnRateAlert {
+	return []BurnRateAlert{
+		{Name: "fast", Severity: SeverityCritical, LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BurnRate: 14.4},
+		{Name: "slow", Severity: SeverityWarning, LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, BurnRate: 6},
+	}
+}
+
+// SLOConfig is the YAML configuration of the endpoint SLOs
+type SLOConfig struct {
+	// Alerts apply to every SLO, DefaultBurnRateAlerts when empty
+	Alerts []BurnRateAlert `yaml:"alerts"`
+	// MinRequests is the number of requests a long window needs before alerting, so
+	// that a couple of slow requests on an idle route do not page
+	MinRequests int `yaml:"min_requests"`
+	// SLOs are the endpoint objectives
+	SLOs []EndpointSLOConfig `yaml:"slos"`
+}
+
+// EndpointSLOConfig defines the SLO of one route
+type EndpointSLOConfig struct {
+	Name             string        `yaml:"name"`
+	Description      string        `yaml:"description,omitempty"`
+	Method           string        `yaml:"method,omitempty"` // Empty means all methods
+	Path             string        `yaml:"path"`
+	LatencyThreshold time.Duration `yaml:"latency_threshold,omitempty"` // Omit for an availability SLO
+	Target           float64       `yaml:"target"`
+	Window           string        `yaml:"window"` // e.g. "3d" for the length of a festival
+}
+
+// LoadSLOConfig loads the endpoint SLOs from a YAML file
+func LoadSLOConfig(path string) (*SLOConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO config: %w", err)
+	}
+
+	var cfg SLOConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse SLO config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks the SLO configuration
+func (c *SLOConfig) Validate() error {
+	for _, a := range c.Alerts {
+		if a.Name == "" {
+			return fmt.Errorf("SLO alert name is required")
+		}
+		if a.LongWindow <= 0 || a.ShortWindow <= 0 || a.ShortWindow > a.LongWindow {
+			return fmt.Errorf("SLO alert %s: short_window must be positive and at most long_window", a.Name)
+		}
+		if a.BurnRate <= 0 {
+			return fmt.Errorf("SLO alert %s: burn_rate must be positive", a.Name)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, s := range c.SLOs {
+		if s.Name == "" || s.Path == "" {
+			return fmt.Errorf("SLO name and path are required")
+		}
+		if names[s.Name] {
+			return fmt.Errorf("SLO %s is defined twice", s.Name)
+		}
+		names[s.Name] = true
+		if s.Target <= 0 || s.Target >= 1 {
+			return fmt.Errorf("SLO %s: target must be between 0 and 1", s.Name)
+		}
+		if _, err := parseWindow(s.Window); err != nil {
+			return fmt.Errorf("SLO %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Configure registers the endpoint SLOs of cfg and the alerts evaluated for them
+func (st *SLOTracker) Configure(cfg *SLOConfig) {
+	st.mu.Lock()
+	if len(cfg.Alerts) > 0 {
+		st.alerts = cfg.Alerts
+	}
+	st.minRequests = uint64(cfg.MinRequests)
+	threshold := 0.0
+	for _, a := range st.alerts {
+		if a.BurnRate > threshold {
+			threshold = a.BurnRate
+		}
+	}
+	st.mu.Unlock()
+
+	for _, s := range cfg.SLOs {
+		st.RegisterSLO(&SLODefinition{
+			Name:              s.Name,
+			Description:       s.Description,
+			Target:            s.Target,
+			Window:            s.Window,
+			BurnRateThreshold: threshold,
+			Method:            strings.ToUpper(s.Method),
+			Path:              s.Path,
+			LatencyThreshold:  s.LatencyThreshold,
+		})
+	}
+}
+
+// Record counts a request against the SLOs of its route, called by
+// Metrics.RecordHTTPRequest
+func (st *SLOTracker) Record(method, path, status string, duration float64) {
+	st.mu.RLock()
+	endpoints := st.routes[routeKey(method, path)]
+	anyMethod := st.routes[routeKey("", path)]
+	st.mu.RUnlock()
+
+	if len(endpoints) == 0 && len(anyMethod) == 0 {
+		return
+	}
+
+	// Non-numeric statuses (e.g. status text) are counted as successes
+	code, _ := strconv.Atoi(status)
+	now := st.now()
+	for _, e := range endpoints {
+		e.record(now, code, duration)
+	}
+	for _, e := range anyMethod {
+		e.record(now, code, duration)
+	}
+}
+
+// Start refreshes the error budget and burn rate gauges until ctx is done
+func (st *SLOTracker) Start(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			st.updateGauges()
+		}
+	}
+}
+
+// updateGauges exports the rolling status of the endpoint SLOs
+func (st *SLOTracker) updateGauges() {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	now := st.now()
+	for name, endpoint := range st.endpoints {
+		s := endpoint.status(now)
+		st.errorBudget.WithLabelValues(name).Set(s.ErrorBudgetRemaining)
+		for window, rate := range s.BurnRates {
+			st.burnRate.WithLabelValues(name, window).Set(rate)
+		}
+	}
+}
+
+// RegisterAlertRules registers a burn-rate rule per endpoint SLO and alert with the
+// alert manager, which sends them to Alertmanager labelled with the SLO
+func (st *SLOTracker) RegisterAlertRules(bam *BusinessAlertManager) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	minRequests := st.minRequests
+	for name, endpoint := range st.endpoints {
+		for _, alert := range st.alerts {
+			endpoint, alert := endpoint, alert
+			bam.RegisterRule(&AlertRule{
+				Name:        fmt.Sprintf("SLOBudgetBurning_%s_%s", name, alert.Name),
+				Description: fmt.Sprintf("%s is burning its error budget %gx faster than sustainable", name, alert.BurnRate),
+				Severity:    alert.Severity,
+				Team:        "sre",
+				Category:    "slo",
+				Threshold:   alert.BurnRate,
+				RunbookURL:  "https://docs.festivals.io/runbooks/slo-burn-rate",
+				Labels: map[string]string{
+					"slo":    name,
+					"window": formatWindow(alert.LongWindow),
+				},
+				EvaluateFunc: func(ctx context.Context) (float64, error) {
+					return endpoint.alertBurnRate(st.now(), alert, minRequests), nil
+				},
+				ConditionFunc: func(value, threshold float64) bool {
+					return value > threshold
+				},
+			})
+		}
+	}
+}
+
+// removeRoute stops routing requests to endpoint. The caller must hold st.mu.
+func (st *SLOTracker) removeRoute(endpoint *endpointSLO) {
+	key := routeKey(endpoint.def.Method, endpoint.def.Path)
+	routes := st.routes[key][:0]
+	for _, e := range st.routes[key] {
+		if e != endpoint {
+			routes = append(routes, e)
+		}
+	}
+	st.routes[key] = routes
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// SLOStatus is the rolling status of an endpoint SLO
+type SLOStatus struct {
+	Good                 uint64             `json:"good"`
+	Total                uint64             `json:"total"`
+	ErrorBudgetRemaining float64            `json:"errorBudgetRemaining"` // Percentage, negative once exhausted
+	BurnRates            map[string]float64 `json:"burnRates"`            // By window
+}
+
+// endpointSLO keeps the rolling request counts of an endpoint SLO
+type endpointSLO struct {
+	def     *SLODefinition
+	window  time.Duration
+	latency float64 // Seconds, 0 for availability only
+	windows []time.Duration
+	fine    *rollingCounter // Burn-rate windows
+	budget  *rollingCounter // Error budget window
+}
+
+func newEndpointSLO(def *SLODefinition, alerts []BurnRateAlert) *endpointSLO {
+	window, err := parseWindow(def.Window)
+	if err != nil {
+		window = defaultSLOWindow
+	}
+
+	var windows []time.Duration
+	span := time.Duration(0)
+	for _, a := range alerts {
+		windows = append(windows, a.ShortWindow, a.LongWindow)
+		if a.LongWindow > span {
+			span = a.LongWindow
+		}
+	}
+
+	width := window / budgetBuckets
+	if width < time.Minute {
+		width = time.Minute
+	}
+
+	return &endpointSLO{
+		def:     def,
+		window:  window,
+		latency: def.LatencyThreshold.Seconds(),
+		windows: windows,
+		fine:    newRollingCounter(span, fineBucketWidth),
+		budget:  newRollingCounter(window, width),
+	}
+}
+
+func (e *endpointSLO) record(now time.Time, status int, duration float64) {
+	good := status < 500 && (e.latency == 0 || duration <= e.latency)
+	e.fine.add(now, good)
+	e.budget.add(now, good)
+}
+
+// burnRate returns how many times faster than sustainable the budget burned over
+// window, and the number of requests it is based on
+func (e *endpointSLO) burnRate(now time.Time, window time.Duration) (float64, uint64) {
+	counter := e.fine
+	if window > e.fine.span() {
+		counter = e.budget
+	}
+
+	good, total := counter.sum(now, window)
+	if total == 0 {
+		return 0, 0
+	}
+	errorRate := float64(total-good) / float64(total)
+	return errorRate / (1 - e.def.Target), total
+}
+
+// alertBurnRate returns the burn rate over both windows of alert, which exceeds the
+// threshold only when both windows do
+func (e *endpointSLO) alertBurnRate(now time.Time, alert BurnRateAlert, minRequests uint64) float64 {
+	long, total := e.burnRate(now, alert.LongWindow)
+	if total == 0 || total < minRequests {
+		return 0
+	}
+	short, _ := e.burnRate(now, alert.ShortWindow)
+	if short < long {
+		return short
+	}
+	return long
+}
+
+func (e *endpointSLO) status(now time.Time) SLOStatus {
+	good, total := e.budget.sum(now, e.window)
+	s := SLOStatus{
+		Good:                 good,
+		Total:                total,
+		ErrorBudgetRemaining: 100,
+		BurnRates:            make(map[string]float64),
+	}
+
+	if total > 0 {
+		budget := float64(total) * (1 - e.def.Target)
+		s.ErrorBudgetRemaining = 100 * (1 - float64(total-good)/budget)
+	}
+	for _, w := range e.windows {
+		s.BurnRates[formatWindow(w)], _ = e.burnRate(now, w)
+	}
+	s.BurnRates[formatWindow(e.window)], _ = e.burnRate(now, e.window)
+	return s
+}
+
+// rollingCounter counts good and total events in a ring of fixed-width time buckets
+type rollingCounter struct {
+	mu      sync.Mutex
+	width   time.Duration
+	buckets []counterBucket
+}
+
+type counterBucket struct {
+	index int64 // Bucket start divided by the width
+	good  uint64
+	total uint64
+}
+
+func newRollingCounter(span, width time.Duration) *rollingCounter {
+	return &rollingCounter{
+		width:   width,
+		buckets: make([]counterBucket, int(span/width)+1),
+	}
+}
+
+func (r *rollingCounter) span() time.Duration {
+	return time.Duration(len(r.buckets)-1) * r.width
+}
+
+func (r *rollingCounter) add(now time.Time, good bool) {
+	index := now.UnixNano() / int64(r.width)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := &r.buckets[index%int64(len(r.buckets))]
+	if b.index != index {
+		*b = counterBucket{index: index}
+	}
+	b.total++
+	if good {
+		b.good++
+	}
+}
+
+// sum returns the counts of the buckets within window of now
+func (r *rollingCounter) sum(now time.Time, window time.Duration) (good, total uint64) {
+	index := now.UnixNano() / int64(r.width)
+	oldest := index - int64(window/r.width)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range r.buckets {
+		if b.index > oldest && b.index <= index {
+			good += b.good
+			total += b.total
+		}
+	}
+	return good, total
+}
+
+// parseWindow parses a duration, also accepting days (e.g. "30d")
+func parseWindow(window string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(window, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", window)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q", window)
+	}
+	return d, nil
+}
+
+// formatWindow formats a window the way Prometheus range selectors do (e.g. "5m", "3d")
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,143 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSLOTracker() (*SLOTracker, *time.Time) {
+	now := time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+	tracker := NewSLOTracker(prometheus.NewRegistry())
+	tracker.now = func() time.Time { return now }
+	tracker.Configure(&SLOConfig{
+		MinRequests: 10,
+		SLOs: []EndpointSLOConfig{{
+			Name:             "order_payment_latency",
+			Method:           "post",
+			Path:             "/api/v1/orders/:id/pay",
+			LatencyThreshold: 500 * time.Millisecond,
+			Target:           0.99,
+			Window:           "3d",
+		}},
+	})
+	return tracker, &now
+}
+
+// TestSLOTracker_ErrorBudget tests that slow and failed requests consume the error
+// budget of their route only
+func TestSLOTracker_ErrorBudget(t *testing.T) {
+	tracker, _ := newTestSLOTracker()
+
+	for i := 0; i < 198; i++ {
+		tracker.Record("POST", "/api/v1/orders/:id/pay", "200", 0.1)
+	}
+	tracker.Record("POST", "/api/v1/orders/:id/pay", "200", 0.8)
+	tracker.Record("POST", "/api/v1/orders/:id/pay", "503", 0.1)
+	tracker.Record("GET", "/api/v1/orders/:id/pay", "500", 0.1)
+	tracker.Record("POST", "/api/v1/orders", "500", 0.1)
+
+	status := tracker.GetSLOStatus("order_payment_latency")
+	require.NotNil(t, status)
+	assert.Equal(t, uint64(200), status["totalRequests"])
+	assert.Equal(t, uint64(198), status["goodRequests"])
+	assert.InDelta(t, 0.0, status["errorBudgetRemaining"], 1e-9, "2 bad requests out of 200 exhaust a 99% budget")
+	assert.InDelta(t, 1.0, status["burnRates"].(map[string]float64)["3d"], 1e-9)
+}
+
+// TestSLOTracker_BurnRateAlert tests that the alert needs both windows to burn fast
+// and ignores routes with too few requests
+func TestSLOTracker_BurnRateAlert(t *testing.T) {
+	tracker, now := newTestSLOTracker()
+	endpoint := tracker.endpoints["order_payment_latency"]
+	fast := DefaultBurnRateAlerts()[0]
+
+	record := func(good, bad int) {
+		for i := 0; i < good; i++ {
+			tracker.Record("POST", "/api/v1/orders/:id/pay", "200", 0.1)
+		}
+		for i := 0; i < bad; i++ {
+			tracker.Record("POST", "/api/v1/orders/:id/pay", "200", 2)
+		}
+	}
+
+	record(0, 5)
+	assert.Zero(t, endpoint.alertBurnRate(*now, fast, tracker.minRequests), "below the minimum number of requests")
+
+	// A quarter of the requests of the last hour are slow, all within the last 5 minutes
+	record(75, 20)
+	assert.InDelta(t, 25.0, endpoint.alertBurnRate(*now, fast, tracker.minRequests), 1e-9)
+
+	// The short window recovers first
+	*now = now.Add(10 * time.Minute)
+	record(50, 0)
+	long, _ := endpoint.burnRate(*now, fast.LongWindow)
+	assert.Greater(t, long, fast.BurnRate)
+	assert.Zero(t, endpoint.alertBurnRate(*now, fast, tracker.minRequests))
+
+	// Requests older than the long window are forgotten
+	*now = now.Add(2 * time.Hour)
+	long, total := endpoint.burnRate(*now, fast.LongWindow)
+	assert.Zero(t, long)
+	assert.Zero(t, total)
+}
+
+// TestLoadSLOConfig tests loading and validating the SLO configuration
+func TestLoadSLOConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "slo.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte(`
+alerts:
+  - name: fast
+    severity: critical
+    long_window: 1h
+    short_window: 5m
+    burn_rate: 14.4
+slos:
+  - name: order_payment_latency
+    method: POST
+    path: /api/v1/orders/:id/pay
+    latency_threshold: 500ms
+    target: 0.99
+    window: 3d
+`), 0o600))
+
+	cfg, err := LoadSLOConfig(valid)
+	require.NoError(t, err)
+	require.Len(t, cfg.SLOs, 1)
+	assert.Equal(t, 500*time.Millisecond, cfg.SLOs[0].LatencyThreshold)
+	assert.Equal(t, time.Hour, cfg.Alerts[0].LongWindow)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte(`
+slos:
+  - name: order_payment_latency
+    path: /api/v1/orders/:id/pay
+    target: 99
+    window: 3d
+`), 0o600))
+
+	_, err = LoadSLOConfig(invalid)
+	assert.Error(t, err)
+}
+
+// TestParseWindow tests parsing windows in days
+func TestParseWindow(t *testing.T) {
+	d, err := parseWindow("30d")
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, d)
+	assert.Equal(t, "30d", formatWindow(d))
+
+	d, err = parseWindow("90m")
+	require.NoError(t, err)
+	assert.Equal(t, "90m", formatWindow(d))
+
+	_, err = parseWindow("soon")
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VirtualIBANClient opens and closes virtual IBANs at a banking-as-a-service provider.
+// Transfers to a virtual IBAN are credited to the collection account and notified to
+// the bank transfer webhook.
+type VirtualIBANClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// VirtualIBANConfig holds the configuration of the virtual IBAN provider
+type VirtualIBANConfig struct {
+	BaseURL string // e.g. https://api.provider.example/v1
+	APIKey  string
+	Timeout time.Duration
+}
+
+// NewVirtualIBANClient creates a virtual IBAN provider client
+func NewVirtualIBANClient(config VirtualIBANConfig) *VirtualIBANClient {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &VirtualIBANClient{
+		baseURL:    strings.TrimRight(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+type virtualIBANRequest struct {
+	Reference string `json:"reference"`
+	Label     string `json:"label,omitempty"`
+}
+
+type virtualIBANResponse struct {
+	IBAN string `json:"iban"`
+}
+
+// OpenIBAN opens a virtual IBAN for a reference, labelled e.g. with the payer name
+func (c *VirtualIBANClient) OpenIBAN(ctx context.Context, reference, label string) (string, error) {
+	body, err := json.Marshal(virtualIBANRequest{Reference: reference, Label: label})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var result virtualIBANResponse
+	if err := c.do(ctx, http.MethodPost, "/virtual-ibans", body, &result); err != nil {
+		return "", err
+	}
+	if result.IBAN == "" {
+		return "", fmt.Errorf("virtual IBAN provider returned no IBAN")
+	}
+	return result.IBAN, nil
+}
+
+// CloseIBAN closes a virtual IBAN; later transfers to it are returned to the payer
+func (c *VirtualIBANClient) CloseIBAN(ctx context.Context, iban string) error {
+	return c.do(ctx, http.MethodDelete, "/virtual-ibans/"+url.PathEscape(iban), nil, nil)
+}
+
+func (c *VirtualIBANClient) do(ctx context.Context, method, path string, body []byte, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call virtual IBAN provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("virtual IBAN provider error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
@@ -58,6 +58,7 @@ const (
 	TypeAggregateAnalytics      = "analytics:aggregate"
 	TypeProcessAnalyticsEvent   = "analytics:event"
 	TypeGenerateAnalyticsReport = "analytics:report"
+	TypeRecomputeAnalyticsDays  = "analytics:recompute_days"
 )
 
 // Queue priority constants
@@ -65,13 +66,22 @@ const (
 	QueueCritical = "critical"
 	QueueDefault  = "default"
 	QueueLow      = "low"
+
+	// Webhook deliveries, one queue per priority class of the subscriptions so that a
+	// backlog of standard deliveries does not hold back the priority ones
+	QueueWebhooksPriority = "webhooks_priority"
+	QueueWebhooksStandard = "webhooks_standard"
+	QueueWebhooksBulk     = "webhooks_bulk"
 )
 
 // Queue configuration
 var QueueConfig = map[string]int{
-	QueueCritical: 6, // Processed 6 times as often as default
-	QueueDefault:  3,
-	QueueLow:      1,
+	QueueCritical:         6, // Processed 6 times as often as default
+	QueueDefault:          3,
+	QueueLow:              1,
+	QueueWebhooksPriority: 5,
+	QueueWebhooksStandard: 2,
+	QueueWebhooksBulk:     1,
 }
 
 // Client wraps asynq.Client with additional methods
@@ -261,7 +271,9 @@ func NewScheduler(redisURL string) (*Scheduler, error) {
 		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
 	}
 
-	scheduler := asynq.NewScheduler(opt, nil)
+	// Cron specs are evaluated in UTC, whatever the server's timezone; tasks working on
+	// festival days compute their boundaries in each festival's timezone
+	scheduler := asynq.NewScheduler(opt, &asynq.SchedulerOpts{Location: time.UTC})
 	log.Info().Msg("Asynq scheduler initialized")
 
 	return &Scheduler{Scheduler: scheduler}, nil
@@ -319,3 +331,22 @@ func (i *Inspector) GetQueueStats() (map[string]*asynq.QueueInfo, error) {
 
 	return stats, nil
 }
+
+// Backlog returns the number of tasks waiting to be processed across all queues:
+// pending, and retrying after a failure
+func (i *Inspector) Backlog() (int, error) {
+	queues, err := i.Queues()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	backlog := 0
+	for _, q := range queues {
+		info, err := i.GetQueueInfo(q)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get queue %s: %w", q, err)
+		}
+		backlog += info.Pending + info.Retry
+	}
+	return backlog, nil
+}
@@ -31,7 +31,30 @@ type TwilioConfig struct {
 	AuthToken  string
 	FromNumber string
 	Timeout    time.Duration
-	RateLimit  int // Messages per second, 0 for no limit
+	RateLimit  int    // Messages per second, 0 for no limit
+	Region     string // Processing region, e.g. ie1; US when empty
+	Edge       string // Edge location, e.g. dublin; the default edge of the region when empty
+}
+
+// twilioDefaultEdges are the edge locations used for a region when none is configured
+var twilioDefaultEdges = map[string]string{
+	"ie1": "dublin",
+	"de1": "frankfurt",
+	"au1": "sydney",
+	"jp1": "tokyo",
+	"br1": "sao-paulo",
+	"sg1": "singapore",
+}
+
+// TwilioHost returns the API host of a Twilio region and edge
+func TwilioHost(region, edge string) string {
+	if region == "" {
+		return "api.twilio.com"
+	}
+	if edge == "" {
+		edge = twilioDefaultEdges[region]
+	}
+	return fmt.Sprintf("api.%s.%s.twilio.com", edge, region)
 }
 
 // rateLimiter implements token bucket rate limiting
@@ -96,7 +119,7 @@ func NewTwilioClient(cfg TwilioConfig) *TwilioClient {
 		accountSID: cfg.AccountSID,
 		authToken:  cfg.AuthToken,
 		fromNumber: cfg.FromNumber,
-		baseURL:    fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s", cfg.AccountSID),
+		baseURL:    fmt.Sprintf("https://%s/2010-04-01/Accounts/%s", TwilioHost(cfg.Region, cfg.Edge), cfg.AccountSID),
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
@@ -0,0 +1,141 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	openMeteoBaseURL   = "https://api.open-meteo.com/v1"
+	openMeteoHourlyVar = "temperature_2m,precipitation,wind_speed_10m,cloud_cover,weather_code"
+)
+
+// OpenMeteoClient retrieves weather from the Open-Meteo API
+type OpenMeteoClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// openMeteoResponse represents the Open-Meteo forecast API response
+type openMeteoResponse struct {
+	Hourly struct {
+		Time          []int64    `json:"time"`
+		Temperature   []*float64 `json:"temperature_2m"`
+		Precipitation []*float64 `json:"precipitation"`
+		WindSpeed     []*float64 `json:"wind_speed_10m"`
+		CloudCover    []*float64 `json:"cloud_cover"`
+		WeatherCode   []*int     `json:"weather_code"`
+	} `json:"hourly"`
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// NewOpenMeteoClient creates a new Open-Meteo client
+func NewOpenMeteoClient(cfg Config) *OpenMeteoClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = openMeteoBaseURL
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &OpenMeteoClient{
+		baseURL: baseURL,
+		apiKey:  cfg.APIKey,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Name returns the provider name
+func (c *OpenMeteoClient) Name() string {
+	return ProviderOpenMeteo
+}
+
+// GetHourly retrieves the hourly observations between start and end (inclusive, UTC days).
+// The forecast endpoint serves the recent past as well as the coming days.
+func (c *OpenMeteoClient) GetHourly(ctx context.Context, latitude, longitude float64, start, end time.Time) ([]Observation, error) {
+	params := url.Values{}
+	params.Set("latitude", strconv.FormatFloat(latitude, 'f', 4, 64))
+	params.Set("longitude", strconv.FormatFloat(longitude, 'f', 4, 64))
+	params.Set("hourly", openMeteoHourlyVar)
+	params.Set("start_date", start.UTC().Format("2006-01-02"))
+	params.Set("end_date", end.UTC().Format("2006-01-02"))
+	params.Set("timezone", "GMT")
+	params.Set("timeformat", "unixtime")
+	params.Set("wind_speed_unit", "kmh")
+	if c.apiKey != "" {
+		params.Set("apikey", c.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/forecast?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result openMeteoResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Open-Meteo API error: status %d, body: %s", resp.StatusCode, string(respBody))
+		}
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error {
+		return nil, fmt.Errorf("Open-Meteo API error: status %d: %s", resp.StatusCode, result.Reason)
+	}
+
+	hourly := result.Hourly
+	observations := make([]Observation, 0, len(hourly.Time))
+	for i, ts := range hourly.Time {
+		obs := Observation{
+			Time:            time.Unix(ts, 0).UTC(),
+			TemperatureC:    floatAt(hourly.Temperature, i),
+			PrecipitationMM: floatAt(hourly.Precipitation, i),
+			WindSpeedKMH:    floatAt(hourly.WindSpeed, i),
+			CloudCoverPct:   floatAt(hourly.CloudCover, i),
+		}
+		if i < len(hourly.WeatherCode) && hourly.WeatherCode[i] != nil {
+			obs.WeatherCode = hourly.WeatherCode[i]
+			obs.Condition = ConditionFromWMOCode(*obs.WeatherCode)
+		} else if obs.TemperatureC == nil {
+			// Hours without data (e.g. beyond the model horizon) are skipped
+			continue
+		} else if obs.PrecipitationMM != nil && *obs.PrecipitationMM > 0 {
+			obs.Condition = ConditionRain
+		} else {
+			obs.Condition = ConditionClear
+		}
+		observations = append(observations, obs)
+	}
+
+	return observations, nil
+}
+
+func floatAt(values []*float64, i int) *float64 {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
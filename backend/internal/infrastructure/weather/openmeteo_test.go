@@ -0,0 +1,94 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenMeteoClient_GetHourly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/forecast", r.URL.Path)
+		assert.Equal(t, "50.8503", r.URL.Query().Get("latitude"))
+		assert.Equal(t, "2024-07-20", r.URL.Query().Get("start_date"))
+		assert.Equal(t, "unixtime", r.URL.Query().Get("timeformat"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hourly":{
+			"time":[1721469600,1721473200,1721476800],
+			"temperature_2m":[21.4,18.2,null],
+			"precipitation":[0,3.1,null],
+			"wind_speed_10m":[12,25,null],
+			"cloud_cover":[10,100,null],
+			"weather_code":[1,63,null]
+		}}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenMeteoClient(Config{BaseURL: server.URL})
+	start := time.Date(2024, 7, 20, 10, 0, 0, 0, time.UTC)
+
+	observations, err := client.GetHourly(context.Background(), 50.8503, 4.3517, start, start.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, observations, 2)
+
+	assert.Equal(t, start, observations[0].Time)
+	assert.Equal(t, ConditionClear, observations[0].Condition)
+	assert.Equal(t, 21.4, *observations[0].TemperatureC)
+
+	assert.Equal(t, ConditionRain, observations[1].Condition)
+	assert.True(t, observations[1].Condition.IsWet())
+	assert.Equal(t, 3.1, *observations[1].PrecipitationMM)
+}
+
+func TestOpenMeteoClient_GetHourly_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":true,"reason":"Latitude must be in range of -90 to 90°."}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenMeteoClient(Config{BaseURL: server.URL})
+
+	_, err := client.GetHourly(context.Background(), 120, 0, time.Now(), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Latitude must be in range")
+}
+
+func TestConditionFromWMOCode(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected Condition
+	}{
+		{0, ConditionClear},
+		{2, ConditionCloudy},
+		{45, ConditionFog},
+		{53, ConditionRain},
+		{81, ConditionRain},
+		{73, ConditionSnow},
+		{86, ConditionSnow},
+		{95, ConditionThunderstorm},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ConditionFromWMOCode(tt.code), "code %d", tt.code)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider(Config{Provider: ProviderNone})
+	require.NoError(t, err)
+	assert.Nil(t, provider)
+
+	provider, err = NewProvider(Config{Provider: ProviderOpenMeteo})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderOpenMeteo, provider.Name())
+
+	_, err = NewProvider(Config{Provider: "unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Supported weather providers
+const (
+	ProviderOpenMeteo = "open-meteo"
+	ProviderNone      = "none"
+)
+
+// Condition is a coarse weather condition used to group observations
+type Condition string
+
+const (
+	ConditionClear        Condition = "CLEAR"
+	ConditionCloudy       Condition = "CLOUDY"
+	ConditionFog          Condition = "FOG"
+	ConditionRain         Condition = "RAIN"
+	ConditionSnow         Condition = "SNOW"
+	ConditionThunderstorm Condition = "THUNDERSTORM"
+)
+
+// IsWet reports whether the condition involves precipitation
+func (c Condition) IsWet() bool {
+	return c == ConditionRain || c == ConditionSnow || c == ConditionThunderstorm
+}
+
+// Observation is the weather measured at a location over one hour
+type Observation struct {
+	Time            time.Time // Start of the hour, UTC
+	TemperatureC    *float64
+	PrecipitationMM *float64
+	WindSpeedKMH    *float64
+	CloudCoverPct   *float64
+	WeatherCode     *int // WMO weather interpretation code
+	Condition       Condition
+}
+
+// Provider retrieves hourly weather observations for a location
+type Provider interface {
+	Name() string
+	GetHourly(ctx context.Context, latitude, longitude float64, start, end time.Time) ([]Observation, error)
+}
+
+// Config holds the weather provider configuration
+type Config struct {
+	Provider string
+	BaseURL  string // Optional, overrides the provider default
+	APIKey   string // Optional for open-meteo, required for its commercial endpoint
+	Timeout  time.Duration
+}
+
+// NewProvider creates the configured provider; it returns nil when weather ingestion is disabled
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", ProviderNone:
+		return nil, nil
+	case ProviderOpenMeteo:
+		return NewOpenMeteoClient(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider: %s", cfg.Provider)
+	}
+}
+
+// ConditionFromWMOCode maps a WMO weather interpretation code to a condition
+func ConditionFromWMOCode(code int) Condition {
+	switch {
+	case code <= 1:
+		return ConditionClear
+	case code <= 3:
+		return ConditionCloudy
+	case code == 45 || code == 48:
+		return ConditionFog
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return ConditionSnow
+	case code >= 95:
+		return ConditionThunderstorm
+	default:
+		// Drizzle (51-57), rain (61-67) and showers (80-82)
+		return ConditionRain
+	}
+}
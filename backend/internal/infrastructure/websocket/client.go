@@ -32,11 +32,16 @@ const (
 type Channel string
 
 const (
-	ChannelDashboard Channel = "dashboard" // Stats, transactions, revenue
-	ChannelAlerts    Channel = "alerts"    // Alerts only
-	ChannelAll       Channel = "all"       // All updates
+	ChannelDashboard Channel = "dashboard"  // Stats, transactions, revenue, activity
+	ChannelAlerts    Channel = "alerts"     // Alerts only
+	ChannelAll       Channel = "all"        // All updates
+	ChannelMenuBoard Channel = "menu_board" // Menu updates for the digital menu boards
 )
 
+// DisplayTokenKey is the client metadata key of the display token a menu board
+// connected with
+const DisplayTokenKey = "display_token_id"
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -94,9 +99,13 @@ func (c *Client) shouldReceive(msgType MessageType) bool {
 			msgType == MessageTypeTransaction ||
 			msgType == MessageTypeRevenueUpdate ||
 			msgType == MessageTypeEntry ||
+			msgType == MessageTypeActivity ||
+			msgType == MessageTypeJob ||
 			msgType == MessageTypePing
 	case ChannelAlerts:
 		return msgType == MessageTypeAlert || msgType == MessageTypePing
+	case ChannelMenuBoard:
+		return msgType == MessageTypeMenuUpdate || msgType == MessageTypePing
 	default:
 		return true
 	}
@@ -247,3 +256,29 @@ func DashboardHandler(hub *Hub) gin.HandlerFunc {
 func AlertsHandler(hub *Hub) gin.HandlerFunc {
 	return WebSocketHandler(hub, ChannelAlerts)
 }
+
+// MenuBoardHandler returns a handler for the menu update connections of the digital
+// menu boards. It runs after the display token was authenticated, which sets the
+// festival and the token in the context; the connections of a token are closed when it
+// is revoked.
+func MenuBoardHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		festivalID := c.GetString("festival_id")
+		tokenID := c.GetString(DisplayTokenKey)
+		if festivalID == "" || tokenID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "display token is required"})
+			return
+		}
+
+		ServeWs(hub, c, ClientConfig{
+			FestivalID: festivalID,
+			Channel:    ChannelMenuBoard,
+			Metadata: map[string]string{
+				DisplayTokenKey: tokenID,
+				"stand_id":      c.GetString("stand_id"),
+				"ip":            c.ClientIP(),
+				"user_agent":    c.Request.UserAgent(),
+			},
+		})
+	}
+}
@@ -17,6 +17,11 @@ const (
 	MessageTypeAlert        MessageType = "alert"
 	MessageTypeEntry        MessageType = "entry"
 	MessageTypeRevenueUpdate MessageType = "revenue_update"
+	MessageTypeActivity     MessageType = "activity"
+	MessageTypeMenuUpdate   MessageType = "menu_update"
+	MessageTypeJob          MessageType = "job"
+	MessageTypeCredentialRevoked MessageType = "credential_revoked"
+	MessageTypeDisplayRevoked MessageType = "display_revoked"
 	MessageTypePing         MessageType = "ping"
 	MessageTypePong         MessageType = "pong"
 )
@@ -109,6 +114,11 @@ func (h *Hub) unregisterClient(client *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	h.removeClient(client)
+}
+
+// removeClient closes the send channel of a client and forgets it; h.mu must be held
+func (h *Hub) removeClient(client *Client) {
 	if clients, ok := h.clients[client.festivalID]; ok {
 		if _, exists := clients[client]; exists {
 			delete(clients, client)
@@ -226,6 +236,64 @@ func (h *Hub) BroadcastRevenueUpdate(festivalID string, revenue interface{}) err
 	return h.BroadcastToFestival(festivalID, MessageTypeRevenueUpdate, revenue)
 }
 
+// BroadcastActivity sends an activity feed entry to a festival
+func (h *Hub) BroadcastActivity(festivalID string, activity interface{}) error {
+	return h.BroadcastToFestival(festivalID, MessageTypeActivity, activity)
+}
+
+// BroadcastMenuUpdate tells the clients of a festival to reload stand menus
+func (h *Hub) BroadcastMenuUpdate(festivalID string, update interface{}) error {
+	return h.BroadcastToFestival(festivalID, MessageTypeMenuUpdate, update)
+}
+
+// BroadcastJob sends the progress of a background job to a festival
+func (h *Hub) BroadcastJob(festivalID string, job interface{}) error {
+	return h.BroadcastToFestival(festivalID, MessageTypeJob, job)
+}
+
+// BroadcastCredentialRevoked tells the POS devices of a festival to refuse a wristband
+// or QR generation from now on
+func (h *Hub) BroadcastCredentialRevoked(festivalID string, revocation interface{}) error {
+	return h.BroadcastToFestival(festivalID, MessageTypeCredentialRevoked, revocation)
+}
+
+// DisconnectClients closes the connections of the clients of a festival whose metadata
+// key has value, e.g. the menu boards of a revoked display token, after sending them a
+// last message of msgType. It returns the number of clients disconnected.
+func (h *Hub) DisconnectClients(festivalID, key, value string, msgType MessageType, data interface{}) (int, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	messageBytes, err := json.Marshal(&Message{
+		Type:       msgType,
+		FestivalID: festivalID,
+		Timestamp:  time.Now(),
+		Data:       dataBytes,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	disconnected := 0
+	for client := range h.clients[festivalID] {
+		if client.metadata[key] != value {
+			continue
+		}
+		select {
+		case client.send <- messageBytes:
+		default:
+			// Buffer full, the client is closed without the message
+		}
+		h.removeClient(client)
+		disconnected++
+	}
+	return disconnected, nil
+}
+
 // GetStats returns hub statistics
 func (h *Hub) GetStats() map[string]interface{} {
 	h.mu.RLock()
@@ -9,15 +9,47 @@ import (
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/pkg/tz"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
+// dashboardRefreshInterval throttles event driven dashboard refreshes per festival;
+// the periodic refresh picks up events skipped in between
+const dashboardRefreshInterval = 5 * time.Second
+
+// DashboardMaterializer refreshes the dashboard aggregates of a festival; satisfied by stats.Service
+type DashboardMaterializer interface {
+	MaterializeDashboard(ctx context.Context, festivalID uuid.UUID) error
+}
+
+// RecommendationRefresher recomputes the menu recommendations of a festival, or of every
+// festival with recent orders when festivalID is nil; satisfied by recommendation.Service
+type RecommendationRefresher interface {
+	RefreshRecommendations(ctx context.Context, festivalID *uuid.UUID) error
+}
+
+// ETAModelTrainer trains the preparation time models of a festival's stands, or of every
+// festival with recent orders when festivalID is nil; satisfied by eta.Service
+type ETAModelTrainer interface {
+	TrainETAModels(ctx context.Context, festivalID *uuid.UUID) error
+}
+
+// StorageBudget tells whether the keys under a prefix are over their memory budget,
+// satisfied by cache.MemoryGovernor
+type StorageBudget interface {
+	OverBudget(prefix string) bool
+}
+
 // AnalyticsWorker handles analytics processing tasks
 type AnalyticsWorker struct {
-	db  *gorm.DB
-	rdb *redis.Client
+	db              *gorm.DB
+	rdb             *redis.Client
+	dashboard       DashboardMaterializer
+	recommendations RecommendationRefresher
+	etaModels       ETAModelTrainer
+	budget          StorageBudget
 }
 
 // NewAnalyticsWorker creates a new analytics worker
@@ -28,10 +60,32 @@ func NewAnalyticsWorker(db *gorm.DB, rdb *redis.Client) *AnalyticsWorker {
 	}
 }
 
+// SetDashboardMaterializer sets the dashboard aggregates refreshed after each event
+func (w *AnalyticsWorker) SetDashboardMaterializer(materializer DashboardMaterializer) {
+	w.dashboard = materializer
+}
+
+// SetRecommendationRefresher sets the menu recommendations refreshed with the periodic metrics
+func (w *AnalyticsWorker) SetRecommendationRefresher(refresher RecommendationRefresher) {
+	w.recommendations = refresher
+}
+
+// SetETAModelTrainer sets the preparation time models trained with the periodic metrics
+func (w *AnalyticsWorker) SetETAModelTrainer(trainer ETAModelTrainer) {
+	w.etaModels = trainer
+}
+
+// SetStorageBudget makes the worker aggregate the user activity instead of keeping a
+// key per user while the analytics keys are over their memory budget
+func (w *AnalyticsWorker) SetStorageBudget(budget StorageBudget) {
+	w.budget = budget
+}
+
 // RegisterHandlers registers all analytics task handlers
 func (w *AnalyticsWorker) RegisterHandlers(server *queue.Server) {
 	server.HandleFunc(queue.TypeProcessAnalytics, w.HandleProcessAnalytics)
 	server.HandleFunc(queue.TypeAggregateAnalytics, w.HandleAggregateAnalytics)
+	server.HandleFunc(queue.TypeRecomputeAnalyticsDays, w.HandleRecomputeAnalyticsDays)
 	server.HandleFunc(queue.TypeProcessAnalyticsEvent, w.HandleProcessAnalyticsEvent)
 	server.HandleFunc(queue.TypeGenerateAnalyticsReport, w.HandleGenerateAnalyticsReport)
 }
@@ -49,7 +103,7 @@ func (w *AnalyticsWorker) HandleProcessAnalytics(ctx context.Context, task *asyn
 		// Default values for scheduled runs
 		payload = ProcessAnalyticsPayload{
 			TimeWindow:  "15m",
-			MetricTypes: []string{"revenue", "transactions", "attendance", "active_users"},
+			MetricTypes: []string{"revenue", "transactions", "attendance", "active_users", "recommendations", "eta_models"},
 		}
 	}
 
@@ -93,6 +147,20 @@ func (w *AnalyticsWorker) HandleProcessAnalytics(ctx context.Context, task *asyn
 			if err := w.processActiveUserMetrics(ctx, payload.FestivalID, windowStart, windowEnd); err != nil {
 				log.Warn().Err(err).Str("metricType", metricType).Msg("Error processing active user metrics")
 			}
+		case "recommendations":
+			if w.recommendations == nil {
+				continue
+			}
+			if err := w.recommendations.RefreshRecommendations(ctx, payload.FestivalID); err != nil {
+				log.Warn().Err(err).Str("metricType", metricType).Msg("Error refreshing recommendations")
+			}
+		case "eta_models":
+			if w.etaModels == nil {
+				continue
+			}
+			if err := w.etaModels.TrainETAModels(ctx, payload.FestivalID); err != nil {
+				log.Warn().Err(err).Str("metricType", metricType).Msg("Error training ETA models")
+			}
 		}
 	}
 
@@ -107,6 +175,11 @@ func (w *AnalyticsWorker) HandleProcessAnalytics(ctx context.Context, task *asyn
 
 // HandleAggregateAnalytics handles analytics aggregation for reporting
 func (w *AnalyticsWorker) HandleAggregateAnalytics(ctx context.Context, task *asynq.Task) error {
+	// Scheduled runs have no payload and aggregate the festival days that just ended
+	if len(task.Payload()) == 0 {
+		return w.aggregateCompletedDays(ctx, time.Now())
+	}
+
 	var payload AggregateAnalyticsPayload
 	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
@@ -152,6 +225,135 @@ func (w *AnalyticsWorker) HandleAggregateAnalytics(ctx context.Context, task *as
 	return nil
 }
 
+// aggregateCompletedDays aggregates the previous operational day of every festival
+// whose day start passed within the last hour, hour by hour and as a whole; the task runs
+// hourly so that each festival is aggregated once its own day is over, whatever its
+// timezone and day start
+func (w *AnalyticsWorker) aggregateCompletedDays(ctx context.Context, now time.Time) error {
+	if w.db == nil {
+		return nil
+	}
+
+	var festivals []struct {
+		ID          uuid.UUID
+		Timezone    string
+		DayStartsAt string
+	}
+	if err := w.db.WithContext(ctx).Table("festivals").
+		Select("id, timezone, day_starts_at").
+		Where("status IN ?", []string{"ACTIVE", "COMPLETED"}).
+		Scan(&festivals).Error; err != nil {
+		return fmt.Errorf("failed to list festivals: %w", err)
+	}
+
+	aggregated := 0
+	for _, festival := range festivals {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		cal := tz.NewCalendar(festival.Timezone, festival.DayStartsAt)
+		today := cal.StartOfDay(now)
+		if now.Sub(today) >= time.Hour {
+			continue // The day start was not within the last hour
+		}
+
+		yesterday, _ := cal.Bounds(cal.DateOf(now).AddDate(0, 0, -1))
+		for _, bucket := range generateTimeBuckets(yesterday, today, "hour") {
+			if err := w.aggregateBucketMetrics(ctx, festival.ID, bucket.Start, bucket.End, defaultAggregateMetrics, false); err != nil {
+				log.Warn().
+					Err(err).
+					Str("festivalId", festival.ID.String()).
+					Time("bucketStart", bucket.Start).
+					Msg("Error aggregating bucket metrics")
+			}
+		}
+		if err := w.aggregateBucketMetrics(ctx, festival.ID, yesterday, today, defaultAggregateMetrics, false); err != nil {
+			log.Warn().
+				Err(err).
+				Str("festivalId", festival.ID.String()).
+				Time("dayStart", yesterday).
+				Msg("Error aggregating day metrics")
+		}
+		aggregated++
+	}
+
+	log.Info().
+		Int("festivalsAggregated", aggregated).
+		Msg("Daily analytics aggregation completed")
+
+	return nil
+}
+
+// HandleRecomputeAnalyticsDays replaces the daily aggregates of a festival after its
+// operational day boundary changed: the days aggregated with the previous boundary are
+// dropped and every operational day over so far is aggregated again
+func (w *AnalyticsWorker) HandleRecomputeAnalyticsDays(ctx context.Context, task *asynq.Task) error {
+	var payload RecomputeAnalyticsDaysPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return w.recomputeDays(ctx, payload.FestivalID, time.Now())
+}
+
+func (w *AnalyticsWorker) recomputeDays(ctx context.Context, festivalID uuid.UUID, now time.Time) error {
+	if w.db == nil {
+		return nil
+	}
+
+	var festivals []struct {
+		Timezone    string
+		DayStartsAt string
+		StartDate   time.Time
+		EndDate     time.Time
+	}
+	if err := w.db.WithContext(ctx).Table("festivals").
+		Select("timezone, day_starts_at, start_date, end_date").
+		Where("id = ?", festivalID).
+		Scan(&festivals).Error; err != nil {
+		return fmt.Errorf("failed to get festival: %w", err)
+	}
+	if len(festivals) == 0 {
+		return nil
+	}
+	festival := festivals[0]
+
+	// Hourly buckets do not depend on the day boundary and are kept
+	if err := w.db.WithContext(ctx).Exec(
+		"DELETE FROM analytics_aggregates WHERE festival_id = ? AND bucket_end - bucket_start > interval '1 hour'",
+		festivalID,
+	).Error; err != nil {
+		return fmt.Errorf("failed to delete daily aggregates: %w", err)
+	}
+
+	cal := tz.NewCalendar(festival.Timezone, festival.DayStartsAt)
+	days := 0
+	for date := festival.StartDate; !date.After(festival.EndDate); date = date.AddDate(0, 0, 1) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		start, end := cal.Bounds(date)
+		if end.After(now) {
+			break // Aggregated by the daily job once over
+		}
+		if err := w.aggregateBucketMetrics(ctx, festivalID, start, end, defaultAggregateMetrics, true); err != nil {
+			log.Warn().
+				Err(err).
+				Str("festivalId", festivalID.String()).
+				Time("dayStart", start).
+				Msg("Error aggregating day metrics")
+		}
+		days++
+	}
+
+	log.Info().
+		Str("festivalId", festivalID.String()).
+		Int("days", days).
+		Msg("Daily analytics recomputed")
+
+	return nil
+}
+
 // HandleProcessAnalyticsEvent handles processing a single analytics event
 func (w *AnalyticsWorker) HandleProcessAnalyticsEvent(ctx context.Context, task *asynq.Task) error {
 	var payload AnalyticsEventPayload
@@ -199,6 +401,8 @@ func (w *AnalyticsWorker) HandleProcessAnalyticsEvent(ctx context.Context, task
 		w.updateRealTimeCounters(ctx, payload)
 	}
 
+	w.refreshDashboard(ctx, payload.FestivalID)
+
 	log.Debug().
 		Str("taskId", taskID).
 		Str("eventId", payload.EventID.String()).
@@ -308,6 +512,9 @@ func parseTimeWindow(window string) time.Duration {
 	}
 }
 
+// defaultAggregateMetrics are the metrics of scheduled daily aggregations
+var defaultAggregateMetrics = []string{"revenue", "transactions", "attendance"}
+
 type TimeBucket struct {
 	Start time.Time
 	End   time.Time
@@ -331,6 +538,10 @@ func generateTimeBuckets(start, end time.Time, granularity string) []TimeBucket
 	current := start
 	for current.Before(end) {
 		bucketEnd := current.Add(step)
+		if granularity == "day" {
+			// Days follow the calendar of start's location, which may have 23 or 25 hours
+			bucketEnd = current.AddDate(0, 0, 1)
+		}
 		if bucketEnd.After(end) {
 			bucketEnd = end
 		}
@@ -570,6 +781,29 @@ func (w *AnalyticsWorker) aggregateBucketMetrics(ctx context.Context, festivalID
 	return nil
 }
 
+// refreshDashboard refreshes the dashboard aggregates of the event's festival, at most
+// once per dashboardRefreshInterval. Failures are logged; the event is already stored.
+func (w *AnalyticsWorker) refreshDashboard(ctx context.Context, festivalID uuid.UUID) {
+	if w.dashboard == nil || festivalID == uuid.Nil {
+		return
+	}
+
+	if w.rdb != nil {
+		key := fmt.Sprintf("analytics:dashboard_refresh:%s", festivalID.String())
+		acquired, err := w.rdb.SetNX(ctx, key, 1, dashboardRefreshInterval).Result()
+		if err == nil && !acquired {
+			return
+		}
+	}
+
+	if err := w.dashboard.MaterializeDashboard(ctx, festivalID); err != nil {
+		log.Warn().
+			Err(err).
+			Str("festivalId", festivalID.String()).
+			Msg("Failed to refresh dashboard aggregates")
+	}
+}
+
 func (w *AnalyticsWorker) updateRealTimeCounters(ctx context.Context, event AnalyticsEventPayload) {
 	// Update event type counter
 	counterKey := fmt.Sprintf("analytics:realtime:%s:%s", event.FestivalID.String(), event.EventType)
@@ -580,11 +814,22 @@ func (w *AnalyticsWorker) updateRealTimeCounters(ctx context.Context, event Anal
 	activityKey := fmt.Sprintf("analytics:activity:%s", event.FestivalID.String())
 	w.rdb.Set(ctx, activityKey, time.Now().Unix(), 24*time.Hour)
 
-	// If user ID is provided, update user activity
-	if event.UserID != nil {
-		userKey := fmt.Sprintf("analytics:user_activity:%s", event.UserID.String())
-		w.rdb.Set(ctx, userKey, time.Now().Unix(), time.Hour)
+	if event.UserID == nil {
+		return
+	}
+
+	// Over budget, the active users of the festival are only counted, per hour, in a
+	// HyperLogLog of a few KB instead of a key per user
+	if w.budget != nil && w.budget.OverBudget("analytics:") {
+		hllKey := fmt.Sprintf("analytics:active_users_hll:%s:%s", event.FestivalID.String(), time.Now().UTC().Format("2006-01-02T15"))
+		w.rdb.PFAdd(ctx, hllKey, event.UserID.String())
+		w.rdb.Expire(ctx, hllKey, 24*time.Hour)
+		return
 	}
+
+	// Update user activity
+	userKey := fmt.Sprintf("analytics:user_activity:%s", event.UserID.String())
+	w.rdb.Set(ctx, userKey, time.Now().Unix(), time.Hour)
 }
 
 func (w *AnalyticsWorker) generateSummaryReport(ctx context.Context, payload GenerateAnalyticsReportPayload) (interface{}, error) {
@@ -626,6 +871,47 @@ func (w *AnalyticsWorker) generateSummaryReport(ctx context.Context, payload Gen
 
 	report["attendance"] = attendanceSummary
 
+	// Staffing recommendations from the last daily run
+	var staffingRecommendations []struct {
+		StandID          uuid.UUID `json:"standId"`
+		StandName        string    `json:"standName"`
+		StartHour        int       `json:"startHour"`
+		EndHour          int       `json:"endHour"`
+		AdditionalStaff  int       `json:"additionalStaff"`
+		AvgOrdersPerHour float64   `json:"avgOrdersPerHour"`
+		Message          string    `json:"message"`
+	}
+	w.db.WithContext(ctx).Table("staffing_recommendations").
+		Select("stand_id, stand_name, start_hour, end_hour, additional_staff, avg_orders_per_hour, message").
+		Where("festival_id = ?", payload.FestivalID).
+		Order("stand_name, start_hour").
+		Scan(&staffingRecommendations)
+
+	report["staffingRecommendations"] = staffingRecommendations
+
+	// Purchase revenue by observed weather condition
+	var weatherSummary []struct {
+		Condition string `json:"condition"`
+		Hours     int64  `json:"hours"`
+		Revenue   int64  `json:"revenue"`
+	}
+	w.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(wo.condition, 'UNKNOWN') as condition, COUNT(*) as hours, SUM(h.revenue) as revenue
+		FROM (
+			SELECT date_trunc('hour', t.created_at) as hour, SUM(ABS(t.amount)) as revenue
+			FROM transactions t
+			INNER JOIN wallets wl ON t.wallet_id = wl.id
+			WHERE wl.festival_id = ? AND t.created_at BETWEEN ? AND ? AND t.type = 'PURCHASE' AND t.status = 'COMPLETED'
+			GROUP BY 1
+		) h
+		LEFT JOIN weather_observations wo ON wo.festival_id = ? AND wo.observed_at = h.hour
+		GROUP BY 1
+		ORDER BY hours DESC`,
+		payload.FestivalID, payload.StartDate, payload.EndDate, payload.FestivalID).
+		Scan(&weatherSummary)
+
+	report["weather"] = weatherSummary
+
 	return report, nil
 }
 
@@ -645,11 +931,12 @@ func (w *AnalyticsWorker) generateDetailedReport(ctx context.Context, payload Ge
 		TxCount int64
 	}
 
+	timezone := w.festivalCalendar(ctx, payload.FestivalID).Location.String()
 	w.db.WithContext(ctx).Table("transactions").
-		Select("EXTRACT(HOUR FROM created_at) as hour, SUM(amount) as revenue, COUNT(*) as tx_count").
+		Select("EXTRACT(HOUR FROM created_at AT TIME ZONE ?) as hour, SUM(amount) as revenue, COUNT(*) as tx_count", timezone).
 		Where("festival_id = ? AND created_at BETWEEN ? AND ? AND status = ?",
 			payload.FestivalID, payload.StartDate, payload.EndDate, "completed").
-		Group("EXTRACT(HOUR FROM created_at)").
+		Group("1").
 		Order("hour").
 		Scan(&hourlyData)
 
@@ -674,11 +961,13 @@ func (w *AnalyticsWorker) generateTrendsReport(ctx context.Context, payload Gene
 		TxCount int64
 	}
 
+	cal := w.festivalCalendar(ctx, payload.FestivalID)
 	w.db.WithContext(ctx).Table("transactions").
-		Select("DATE(created_at) as date, SUM(amount) as revenue, COUNT(*) as tx_count").
+		Select("((created_at AT TIME ZONE ?) - make_interval(mins => ?))::date as date, SUM(amount) as revenue, COUNT(*) as tx_count",
+			cal.Location.String(), cal.Offset()).
 		Where("festival_id = ? AND created_at BETWEEN ? AND ? AND status = ?",
 			payload.FestivalID, payload.StartDate, payload.EndDate, "completed").
-		Group("DATE(created_at)").
+		Group("1").
 		Order("date").
 		Scan(&dailyTrends)
 
@@ -717,6 +1006,20 @@ func (w *AnalyticsWorker) generateComparisonReport(ctx context.Context, payload
 	}, nil
 }
 
+// festivalCalendar returns the calendar in which a festival's hours and operational days
+// are reported
+func (w *AnalyticsWorker) festivalCalendar(ctx context.Context, festivalID uuid.UUID) tz.Calendar {
+	var festival struct {
+		Timezone    string
+		DayStartsAt string
+	}
+	w.db.WithContext(ctx).Table("festivals").
+		Select("timezone, day_starts_at").
+		Where("id = ?", festivalID).
+		Scan(&festival)
+	return tz.NewCalendar(festival.Timezone, festival.DayStartsAt)
+}
+
 func (w *AnalyticsWorker) storeReport(ctx context.Context, reportID uuid.UUID, format string, data interface{}) error {
 	if w.db == nil {
 		return nil
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+)
+
+// AnalyticsQueue enqueues analytics tasks on behalf of the API to the analytics worker
+type AnalyticsQueue struct {
+	client *queue.Client
+}
+
+// NewAnalyticsQueue creates a new analytics queue
+func NewAnalyticsQueue(client *queue.Client) *AnalyticsQueue {
+	return &AnalyticsQueue{client: client}
+}
+
+// RecomputeDays enqueues the recomputation of the daily aggregates of a festival, after
+// its operational day boundary changed
+func (q *AnalyticsQueue) RecomputeDays(ctx context.Context, festivalID uuid.UUID) error {
+	task, err := NewRecomputeAnalyticsDaysTask(&RecomputeAnalyticsDaysPayload{FestivalID: festivalID})
+	if err != nil {
+		return fmt.Errorf("failed to create recompute task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue recompute task: %w", err)
+	}
+	return nil
+}
@@ -9,9 +9,14 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/mimi6060/festivals/backend/internal/config"
+	"github.com/mimi6060/festivals/backend/internal/domain/branding"
+	"github.com/mimi6060/festivals/backend/internal/domain/residency"
+	"github.com/mimi6060/festivals/backend/internal/domain/suppression"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
 	"github.com/rs/zerolog/log"
 )
 
@@ -20,6 +25,14 @@ type EmailWorker struct {
 	config     *config.Config
 	httpClient *http.Client
 	templates  *template.Template
+	suppressor DeliverySuppressor
+	branding   EmailBrandingProvider
+	residency  ResidencyRouter
+}
+
+// EmailBrandingProvider returns a festival's sender name and template branding; satisfied by branding.Service
+type EmailBrandingProvider interface {
+	GetEmailBranding(ctx context.Context, festivalID uuid.UUID) (*branding.EmailBranding, error)
 }
 
 // NewEmailWorker creates a new email worker
@@ -33,6 +46,21 @@ func NewEmailWorker(cfg *config.Config) *EmailWorker {
 	}
 }
 
+// SetSuppressor sets the suppression list checked before each email is sent
+func (w *EmailWorker) SetSuppressor(suppressor DeliverySuppressor) {
+	w.suppressor = suppressor
+}
+
+// SetBrandingProvider sets the source of festival branding applied to emails
+func (w *EmailWorker) SetBrandingProvider(provider EmailBrandingProvider) {
+	w.branding = provider
+}
+
+// SetResidencyRouter sets the residency policies that pick the Postal server of each festival
+func (w *EmailWorker) SetResidencyRouter(router ResidencyRouter) {
+	w.residency = router
+}
+
 // RegisterHandlers registers all email task handlers
 func (w *EmailWorker) RegisterHandlers(server *queue.Server) {
 	server.HandleFunc(queue.TypeSendEmail, w.HandleSendEmail)
@@ -54,14 +82,20 @@ func (w *EmailWorker) HandleSendEmail(ctx context.Context, task *asynq.Task) err
 		Str("template", payload.Template).
 		Msg("Processing send email task")
 
+	templateData := payload.TemplateData
+	if templateData == nil {
+		templateData = map[string]interface{}{}
+	}
+	sender := w.applyBranding(ctx, payload.FestivalID, templateData)
+
 	// Render email template
-	htmlContent, err := w.renderTemplate(payload.Template, payload.TemplateData)
+	htmlContent, err := w.renderTemplate(payload.Template, emailLocale(payload.Locale), templateData)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
 	// Send email via Postal API
-	if err := w.sendEmail(ctx, payload.To, payload.Subject, htmlContent, payload.Attachments); err != nil {
+	if err := w.sendEmail(ctx, payload.FestivalID, sender, payload.To, payload.Subject, htmlContent, payload.Attachments); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -85,23 +119,27 @@ func (w *EmailWorker) HandleSendWelcomeEmail(ctx context.Context, task *asynq.Ta
 		Str("name", payload.Name).
 		Msg("Processing welcome email task")
 
+	locale := emailLocale(payload.Locale)
+
 	templateData := map[string]interface{}{
 		"Name":         payload.Name,
 		"FestivalName": payload.FestivalName,
 		"Year":         time.Now().Year(),
 	}
 
-	subject := "Welcome to Festivals!"
+	subject := i18n.T(locale, "email.welcome.subject")
 	if payload.FestivalName != "" {
-		subject = fmt.Sprintf("Welcome to %s!", payload.FestivalName)
+		subject = i18n.T(locale, "email.welcome.subject_festival", i18n.Params{"festival": payload.FestivalName})
 	}
 
-	htmlContent, err := w.renderTemplate("welcome", templateData)
+	sender := w.applyBranding(ctx, payload.FestivalID, templateData)
+
+	htmlContent, err := w.renderTemplate("welcome", locale, templateData)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
-	if err := w.sendEmail(ctx, payload.Email, subject, htmlContent, nil); err != nil {
+	if err := w.sendEmail(ctx, payload.FestivalID, sender, payload.Email, subject, htmlContent, nil); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -126,20 +164,24 @@ func (w *EmailWorker) HandleSendTicketEmail(ctx context.Context, task *asynq.Tas
 		Str("festivalName", payload.FestivalName).
 		Msg("Processing ticket email task")
 
+	locale := emailLocale(payload.Locale)
+
 	templateData := map[string]interface{}{
 		"Name":         payload.Name,
 		"FestivalName": payload.FestivalName,
 		"TicketType":   payload.TicketType,
 		"TicketCode":   payload.TicketCode,
 		"QRCodeURL":    payload.QRCodeURL,
-		"EventDate":    payload.EventDate.Format("Monday, January 2, 2006"),
+		"EventDate":    i18n.FormatDate(locale, payload.EventDate),
 		"Venue":        payload.Venue,
 		"Year":         time.Now().Year(),
 	}
 
-	subject := fmt.Sprintf("Your Ticket for %s", payload.FestivalName)
+	subject := i18n.T(locale, "email.ticket.subject", i18n.Params{"festival": payload.FestivalName})
 
-	htmlContent, err := w.renderTemplate("ticket", templateData)
+	sender := w.applyBranding(ctx, &payload.FestivalID, templateData)
+
+	htmlContent, err := w.renderTemplate("ticket", locale, templateData)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
@@ -154,7 +196,7 @@ func (w *EmailWorker) HandleSendTicketEmail(ctx context.Context, task *asynq.Tas
 		})
 	}
 
-	if err := w.sendEmail(ctx, payload.Email, subject, htmlContent, attachments); err != nil {
+	if err := w.sendEmail(ctx, &payload.FestivalID, sender, payload.Email, subject, htmlContent, attachments); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -179,28 +221,35 @@ func (w *EmailWorker) HandleSendRefundNotification(ctx context.Context, task *as
 		Int64("amount", payload.Amount).
 		Msg("Processing refund notification task")
 
+	locale := emailLocale(payload.Locale)
+
 	// Format amount for display
-	amountDisplay := fmt.Sprintf("%.2f %s", float64(payload.Amount)/100, payload.Currency)
+	amountDisplay := i18n.FormatAmount(locale, payload.Amount, payload.Currency)
 
 	templateData := map[string]interface{}{
 		"Name":          payload.Name,
 		"FestivalName":  payload.FestivalName,
 		"Amount":        amountDisplay,
 		"Reason":        payload.Reason,
-		"Status":        payload.Status,
-		"ProcessedAt":   payload.ProcessedAt.Format("January 2, 2006 at 3:04 PM"),
+		"Status":        refundStatusKey(payload.Status),
+		"ProcessedAt":   i18n.FormatDateTime(locale, payload.ProcessedAt),
 		"TransactionID": payload.TransactionID.String(),
 		"Year":          time.Now().Year(),
 	}
 
-	subject := fmt.Sprintf("Refund %s - %s", getRefundStatusText(payload.Status), payload.FestivalName)
+	subject := i18n.T(locale, "email.refund.subject", i18n.Params{
+		"status":   i18n.T(locale, "email.refund.status."+refundStatusKey(payload.Status)),
+		"festival": payload.FestivalName,
+	})
+
+	sender := w.applyBranding(ctx, &payload.FestivalID, templateData)
 
-	htmlContent, err := w.renderTemplate("refund_notification", templateData)
+	htmlContent, err := w.renderTemplate("refund_notification", locale, templateData)
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
-	if err := w.sendEmail(ctx, payload.Email, subject, htmlContent, nil); err != nil {
+	if err := w.sendEmail(ctx, &payload.FestivalID, sender, payload.Email, subject, htmlContent, nil); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -212,13 +261,45 @@ func (w *EmailWorker) HandleSendRefundNotification(ctx context.Context, task *as
 	return nil
 }
 
-// sendEmail sends an email via Postal API
-func (w *EmailWorker) sendEmail(ctx context.Context, to, subject, htmlContent string, attachments []EmailAttachment) error {
+// applyBranding adds the festival branding to the template data and returns it for
+// the sender; nil when the festival is unknown or has no provider configured
+func (w *EmailWorker) applyBranding(ctx context.Context, festivalID *uuid.UUID, templateData map[string]interface{}) *branding.EmailBranding {
+	if w.branding == nil || festivalID == nil || *festivalID == uuid.Nil {
+		return nil
+	}
+
+	emailBranding, err := w.branding.GetEmailBranding(ctx, *festivalID)
+	if err != nil {
+		log.Warn().Err(err).Str("festivalId", festivalID.String()).Msg("Failed to load festival branding, using defaults")
+		return nil
+	}
+
+	templateData["Branding"] = emailBranding
+	return emailBranding
+}
+
+// sendEmail sends an email via Postal API, from the festival sender name when branding is set.
+// Emails of festivals pinned to the EU go through the EU Postal server when the default one is not in the EU.
+func (w *EmailWorker) sendEmail(ctx context.Context, festivalID *uuid.UUID, sender *branding.EmailBranding, to, subject, htmlContent string, attachments []EmailAttachment) error {
 	if w.config.PostalURL == "" || w.config.PostalAPIKey == "" {
 		log.Warn().Msg("Postal not configured, skipping email send")
 		return nil // Don't fail if email is not configured
 	}
 
+	postalURL, postalAPIKey := w.config.PostalURL, w.config.PostalAPIKey
+	provider, err := routeProvider(ctx, w.residency, festivalID, residency.KindEmail)
+	if err != nil {
+		return err
+	}
+	if provider != nil && provider.ID == residency.ProviderPostalEU {
+		postalURL, postalAPIKey = w.config.PostalEUURL, w.config.PostalEUAPIKey
+	}
+
+	if isSuppressed(ctx, w.suppressor, suppression.ChannelEmail, to) {
+		log.Info().Str("to", to).Msg("Recipient is on the suppression list, skipping email")
+		return nil
+	}
+
 	// Prepare Postal API request
 	requestBody := map[string]interface{}{
 		"to":      []string{to},
@@ -227,6 +308,13 @@ func (w *EmailWorker) sendEmail(ctx context.Context, to, subject, htmlContent st
 		"from":    "noreply@festivals.app",
 	}
 
+	if sender != nil {
+		requestBody["from"] = fmt.Sprintf("%s <noreply@festivals.app>", sender.SenderName)
+		if sender.ReplyTo != "" {
+			requestBody["reply_to"] = sender.ReplyTo
+		}
+	}
+
 	if len(attachments) > 0 {
 		requestBody["attachments"] = attachments
 	}
@@ -236,13 +324,13 @@ func (w *EmailWorker) sendEmail(ctx context.Context, to, subject, htmlContent st
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", w.config.PostalURL+"/api/v1/send/message", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", postalURL+"/api/v1/send/message", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Server-API-Key", w.config.PostalAPIKey)
+	req.Header.Set("X-Server-API-Key", postalAPIKey)
 
 	resp, err := w.httpClient.Do(req)
 	if err != nil {
@@ -257,17 +345,22 @@ func (w *EmailWorker) sendEmail(ctx context.Context, to, subject, htmlContent st
 	return nil
 }
 
-// renderTemplate renders an email template with the given data
-func (w *EmailWorker) renderTemplate(templateName string, data map[string]interface{}) (string, error) {
+// renderTemplate renders an email template with the given data in the given locale
+func (w *EmailWorker) renderTemplate(templateName, locale string, data map[string]interface{}) (string, error) {
 	if w.templates == nil {
 		// If templates are not loaded, use a simple fallback
-		return renderFallbackTemplate(templateName, data)
+		return renderFallbackTemplate(templateName, locale, data)
+	}
+
+	tmpl, err := w.templates.Clone()
+	if err != nil {
+		return renderFallbackTemplate(templateName, locale, data)
 	}
 
 	var buf bytes.Buffer
-	if err := w.templates.ExecuteTemplate(&buf, templateName+".html", data); err != nil {
+	if err := tmpl.Funcs(translateFuncs(locale)).ExecuteTemplate(&buf, templateName+".html", data); err != nil {
 		// Try fallback if template not found
-		return renderFallbackTemplate(templateName, data)
+		return renderFallbackTemplate(templateName, locale, data)
 	}
 
 	return buf.String(), nil
@@ -280,8 +373,30 @@ func loadEmailTemplates() *template.Template {
 	return nil
 }
 
+// translateFuncs returns the template functions translating catalog keys into the locale:
+// {{t "email.common.greeting" "name" .Name}} substitutes {name} in the message
+func translateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, pairs ...interface{}) string {
+			params := i18n.Params{}
+			for i := 0; i+1 < len(pairs); i += 2 {
+				params[fmt.Sprint(pairs[i])] = pairs[i+1]
+			}
+			return i18n.T(locale, key, params)
+		},
+	}
+}
+
+// emailLocale returns the supported locale of a payload, defaulting to English
+func emailLocale(locale string) string {
+	if normalized := i18n.Normalize(locale); normalized != "" {
+		return normalized
+	}
+	return i18n.Default
+}
+
 // renderFallbackTemplate renders a simple fallback template
-func renderFallbackTemplate(templateName string, data map[string]interface{}) (string, error) {
+func renderFallbackTemplate(templateName, locale string, data map[string]interface{}) (string, error) {
 	templates := map[string]string{
 		"welcome": `
 <!DOCTYPE html>
@@ -290,7 +405,7 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
         .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background: #6366f1; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .header { background: {{with .Branding}}{{.PrimaryColor}}{{else}}#6366f1{{end}}; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
         .content { background: #f9fafb; padding: 30px; }
         .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
     </style>
@@ -298,15 +413,16 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
 <body>
     <div class="container">
         <div class="header">
-            <h1>Welcome!</h1>
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t "email.welcome.title"}}</h1>
         </div>
         <div class="content">
-            <p>Hi {{.Name}},</p>
-            <p>Welcome to {{if .FestivalName}}{{.FestivalName}}{{else}}Festivals{{end}}! We're excited to have you on board.</p>
-            <p>Get ready for an amazing experience!</p>
+            <p>{{t "email.common.greeting" "name" .Name}}</p>
+            <p>{{t "email.welcome.intro" "festival" (or .FestivalName "Festivals")}}</p>
+            <p>{{t "email.welcome.outro"}}</p>
         </div>
         <div class="footer">
-            <p>&copy; {{.Year}} Festivals. All rights reserved.</p>
+            <p>{{t "email.common.footer" "year" .Year}}</p>
         </div>
     </div>
 </body>
@@ -318,7 +434,7 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
         .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background: #6366f1; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .header { background: {{with .Branding}}{{.PrimaryColor}}{{else}}#6366f1{{end}}; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
         .content { background: #f9fafb; padding: 30px; }
         .ticket-info { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
         .ticket-code { font-size: 24px; font-weight: bold; text-align: center; color: #6366f1; padding: 10px; background: #eef2ff; border-radius: 4px; }
@@ -328,21 +444,22 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
 <body>
     <div class="container">
         <div class="header">
-            <h1>Your Ticket is Ready!</h1>
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t "email.ticket.title"}}</h1>
         </div>
         <div class="content">
-            <p>Hi {{.Name}},</p>
-            <p>Here's your ticket for <strong>{{.FestivalName}}</strong>!</p>
+            <p>{{t "email.common.greeting" "name" .Name}}</p>
+            <p>{{t "email.ticket.intro" "festival" .FestivalName}}</p>
             <div class="ticket-info">
-                <p><strong>Ticket Type:</strong> {{.TicketType}}</p>
-                <p><strong>Event Date:</strong> {{.EventDate}}</p>
-                {{if .Venue}}<p><strong>Venue:</strong> {{.Venue}}</p>{{end}}
+                <p><strong>{{t "email.ticket.type"}}:</strong> {{.TicketType}}</p>
+                <p><strong>{{t "email.ticket.date"}}:</strong> {{.EventDate}}</p>
+                {{if .Venue}}<p><strong>{{t "email.ticket.venue"}}:</strong> {{.Venue}}</p>{{end}}
                 <div class="ticket-code">{{.TicketCode}}</div>
             </div>
-            <p>Please show this QR code at the entrance. You can also find your ticket in the app.</p>
+            <p>{{t "email.ticket.instructions"}}</p>
         </div>
         <div class="footer">
-            <p>&copy; {{.Year}} Festivals. All rights reserved.</p>
+            <p>{{t "email.common.footer" "year" .Year}}</p>
         </div>
     </div>
 </body>
@@ -354,7 +471,7 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
         .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background: #6366f1; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .header { background: {{with .Branding}}{{.PrimaryColor}}{{else}}#6366f1{{end}}; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
         .content { background: #f9fafb; padding: 30px; }
         .refund-info { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
         .amount { font-size: 24px; font-weight: bold; color: #10b981; }
@@ -364,21 +481,257 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
 <body>
     <div class="container">
         <div class="header">
-            <h1>Refund Update</h1>
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t "email.refund.title"}}</h1>
         </div>
         <div class="content">
-            <p>Hi {{.Name}},</p>
-            <p>Your refund for <strong>{{.FestivalName}}</strong> has been {{.Status}}.</p>
+            <p>{{t "email.common.greeting" "name" .Name}}</p>
+            <p>{{t (print "email.refund.intro." .Status) "festival" .FestivalName}}</p>
             <div class="refund-info">
                 <p class="amount">{{.Amount}}</p>
-                {{if .Reason}}<p><strong>Reason:</strong> {{.Reason}}</p>{{end}}
-                <p><strong>Processed:</strong> {{.ProcessedAt}}</p>
-                <p><strong>Reference:</strong> {{.TransactionID}}</p>
+                {{if .Reason}}<p><strong>{{t "email.refund.reason"}}:</strong> {{.Reason}}</p>{{end}}
+                <p><strong>{{t "email.refund.processed_at"}}:</strong> {{.ProcessedAt}}</p>
+                <p><strong>{{t "email.refund.reference"}}:</strong> {{.TransactionID}}</p>
+            </div>
+            <p>{{t "email.refund.delay"}}</p>
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"wallet_statement": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: {{with .Branding}}{{.PrimaryColor}}{{else}}#6366f1{{end}}; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t "email.statement.title"}}</h1>
+        </div>
+        <div class="content">
+            <p>{{t "email.statement.intro" "festival" .FestivalName "from" .From "to" .To}}</p>
+            <p>{{t "email.statement.outro"}}</p>
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"duplicate_charge": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: {{with .Branding}}{{.PrimaryColor}}{{else}}#6366f1{{end}}; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .charge-info { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
+        .amount { font-size: 24px; font-weight: bold; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t "email.duplicate_charge.title"}}</h1>
+        </div>
+        <div class="content">
+            <p>{{t (print "email.duplicate_charge.intro." .Status) "festival" .FestivalName}}</p>
+            <div class="charge-info">
+                <p class="amount">{{.Amount}}</p>
+                <p><strong>{{t "email.duplicate_charge.stand"}}:</strong> {{.StandName}}</p>
+                <p><strong>{{t "email.duplicate_charge.charged_at"}}:</strong> {{.ChargedAt}}</p>
+            </div>
+            <p>{{t (print "email.duplicate_charge.outro." .Status)}}</p>
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"wallet_freeze": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: {{with .Branding}}{{.PrimaryColor}}{{else}}#6366f1{{end}}; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .freeze-info { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t (print "email.wallet_freeze.title." .Status)}}</h1>
+        </div>
+        <div class="content">
+            {{if eq .Status "frozen"}}
+            <p>{{t "email.wallet_freeze.intro.frozen" "festival" .FestivalName}}</p>
+            <div class="freeze-info">
+                <p><strong>{{t "email.wallet_freeze.reason"}}:</strong> {{.Reason}}</p>
+                {{if .Until}}<p><strong>{{t "email.wallet_freeze.until"}}:</strong> {{.Until}}</p>{{end}}
+            </div>
+            <p>{{t "email.wallet_freeze.outro.frozen"}}</p>
+            {{else}}
+            <p>{{if .Automatic}}{{t "email.wallet_freeze.intro.thawed" "festival" .FestivalName}}{{else}}{{t "email.wallet_freeze.intro.unfrozen" "festival" .FestivalName}}{{end}}</p>
+            <p>{{t "email.wallet_freeze.outro.unfrozen"}}</p>
+            {{end}}
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"product_recall": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #dc2626; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .recall-info { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
+        .product { font-size: 20px; font-weight: bold; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t "email.recall.title"}}</h1>
+        </div>
+        <div class="content">
+            <p>{{t "email.recall.intro" "product" .ProductName "festival" .FestivalName "reason" .Reason}}</p>
+            <div class="recall-info">
+                <p class="product">{{.ProductName}}</p>
+                <p><strong>{{t "email.recall.stand"}}:</strong> {{.StandName}}</p>
+                <p><strong>{{t "email.recall.purchased_at"}}:</strong> {{.PurchasedAt}}</p>
+            </div>
+            <p><strong>{{t "email.recall.advice"}}</strong></p>
+            <p>{{t (print "email.recall.outro." .Status) "amount" .RefundedAmount}}</p>
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"menu_change": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #6366f1; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .note { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            {{with .Branding}}{{if .LogoURL}}<img src="{{.LogoURL}}" alt="" style="max-height: 48px;">{{end}}{{end}}
+            <h1>{{t (print "email.menu_change.title." .Status)}}</h1>
+        </div>
+        <div class="content">
+            <p>{{t (print "email.menu_change.intro." .Status "." .Action) "festival" .FestivalName "product" .ProductName "stand" .StandName}}</p>
+            {{if eq .Status "approved"}}<p>{{if .PublishAt}}{{t "email.menu_change.published_at" "date" .PublishAt}}{{else}}{{t "email.menu_change.published_now"}}{{end}}</p>{{end}}
+            {{if .Note}}<div class="note">
+                <p><strong>{{t "email.menu_change.note"}}:</strong> {{.Note}}</p>
+            </div>{{end}}
+            {{if eq .Status "rejected"}}<p>{{t "email.menu_change.outro.rejected"}}</p>{{end}}
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"role_change": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #6366f1; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .change-info { background: white; border-radius: 8px; padding: 20px; margin: 20px 0; border: 1px solid #e5e7eb; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{t "email.role_change.title"}}</h1>
+        </div>
+        <div class="content">
+            <p>{{t (print "email.role_change.intro." .Recipient) "actor" .ActorName "name" .UserName "email" .UserEmail "old" .OldRole "new" .NewRole}}</p>
+            <div class="change-info">
+                <p><strong>{{t "email.role_change.changed_at"}}:</strong> {{.ChangedAt}}</p>
+                {{if .Reason}}<p><strong>{{t "email.role_change.reason"}}:</strong> {{.Reason}}</p>{{end}}
+            </div>
+            <p>{{t (print "email.role_change.outro." .Recipient)}}</p>
+        </div>
+        <div class="footer">
+            <p>{{t "email.common.footer" "year" .Year}}</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		"magic_link": `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #6366f1; color: white; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
+        .content { background: #f9fafb; padding: 30px; }
+        .action { text-align: center; margin: 30px 0; }
+        .action a { background: #6366f1; color: white; padding: 12px 24px; border-radius: 6px; text-decoration: none; font-weight: bold; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{t "email.magic_link.title"}}</h1>
+        </div>
+        <div class="content">
+            <p>{{t "email.magic_link.intro" "email" .Email}}</p>
+            <div class="action">
+                <a href="{{.URL}}">{{t "email.magic_link.button"}}</a>
             </div>
-            <p>The amount will be credited to your original payment method within 5-10 business days.</p>
+            <p>{{t "email.magic_link.expiry" "minutes" .ExpiresInMinutes}}</p>
+            <p>{{t "email.magic_link.outro"}}</p>
         </div>
         <div class="footer">
-            <p>&copy; {{.Year}} Festivals. All rights reserved.</p>
+            <p>{{t "email.common.footer" "year" .Year}}</p>
         </div>
     </div>
 </body>
@@ -390,7 +743,7 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
 		return "", fmt.Errorf("template %s not found", templateName)
 	}
 
-	tmpl, err := template.New(templateName).Parse(tmplStr)
+	tmpl, err := template.New(templateName).Funcs(translateFuncs(locale)).Parse(tmplStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -403,14 +756,12 @@ func renderFallbackTemplate(templateName string, data map[string]interface{}) (s
 	return buf.String(), nil
 }
 
-// getRefundStatusText returns a human-readable refund status
-func getRefundStatusText(status string) string {
+// refundStatusKey returns the catalog key suffix of a refund status
+func refundStatusKey(status string) string {
 	switch status {
-	case "processed":
-		return "Processed"
-	case "failed":
-		return "Failed"
+	case "processed", "failed":
+		return status
 	default:
-		return "Update"
+		return "update"
 	}
 }
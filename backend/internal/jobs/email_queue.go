@@ -0,0 +1,279 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/duplicatecharge"
+	"github.com/mimi6060/festivals/backend/internal/domain/magiclink"
+	"github.com/mimi6060/festivals/backend/internal/domain/recall"
+	"github.com/mimi6060/festivals/backend/internal/domain/user"
+	"github.com/mimi6060/festivals/backend/internal/domain/vendorportal"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+)
+
+// EmailQueue enqueues emails sent on behalf of the API to the email worker
+type EmailQueue struct {
+	client *queue.Client
+}
+
+// NewEmailQueue creates a new email queue
+func NewEmailQueue(client *queue.Client) *EmailQueue {
+	return &EmailQueue{client: client}
+}
+
+// SendWalletStatement enqueues a wallet statement email with the PDF attached
+func (q *EmailQueue) SendWalletStatement(ctx context.Context, email wallet.StatementEmail) error {
+	locale := emailLocale(email.Locale)
+	festivalID := email.FestivalID
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       email.To,
+		Subject:  i18n.T(locale, "email.statement.subject", i18n.Params{"festival": email.FestivalName}),
+		Template: "wallet_statement",
+		TemplateData: map[string]interface{}{
+			"FestivalName": email.FestivalName,
+			"From":         i18n.FormatDate(locale, email.From),
+			"To":           i18n.FormatDate(locale, email.Until),
+			"Year":         time.Now().Year(),
+		},
+		Attachments: []EmailAttachment{{
+			Filename:    email.Filename,
+			ContentType: "application/pdf",
+			Content:     email.PDF,
+		}},
+		FestivalID: &festivalID,
+		Locale:     locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// NotifyDuplicateCharge enqueues the email telling a wallet holder that a duplicate
+// charge was reversed or is under review
+func (q *EmailQueue) NotifyDuplicateCharge(ctx context.Context, notice duplicatecharge.Notice) error {
+	locale := emailLocale(notice.Locale)
+	festivalID := notice.FestivalID
+	status := "review"
+	if notice.Reversed {
+		status = "reversed"
+	}
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       notice.To,
+		Subject:  i18n.T(locale, "email.duplicate_charge.subject."+status, i18n.Params{"festival": notice.FestivalName}),
+		Template: "duplicate_charge",
+		TemplateData: map[string]interface{}{
+			"Status":       status,
+			"FestivalName": notice.FestivalName,
+			"StandName":    notice.StandName,
+			"Amount":       i18n.FormatAmount(locale, notice.Amount, notice.CurrencyName),
+			"ChargedAt":    i18n.FormatDateTime(locale, notice.ChargedAt),
+			"Year":         time.Now().Year(),
+		},
+		FestivalID: &festivalID,
+		Locale:     locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// NotifyWalletFreeze enqueues the email telling a wallet holder that their wallet was
+// frozen or unfrozen
+func (q *EmailQueue) NotifyWalletFreeze(ctx context.Context, notice wallet.FreezeNotice) error {
+	locale := emailLocale(notice.Locale)
+	festivalID := notice.FestivalID
+	status, reason := "unfrozen", ""
+	if notice.Frozen {
+		status = "frozen"
+		reason = i18n.T(locale, "email.wallet_freeze.reasons."+string(notice.Reason))
+	}
+	until := ""
+	if notice.Until != nil {
+		until = i18n.FormatDateTime(locale, *notice.Until)
+	}
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       notice.To,
+		Subject:  i18n.T(locale, "email.wallet_freeze.subject."+status, i18n.Params{"festival": notice.FestivalName}),
+		Template: "wallet_freeze",
+		TemplateData: map[string]interface{}{
+			"Status":       status,
+			"FestivalName": notice.FestivalName,
+			"Reason":       reason,
+			"Until":        until,
+			"Automatic":    notice.Automatic,
+			"Year":         time.Now().Year(),
+		},
+		Priority:   "high",
+		FestivalID: &festivalID,
+		Locale:     locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// NotifyRecall enqueues the email telling an attendee that a product they bought was
+// recalled, and whether it was refunded
+func (q *EmailQueue) NotifyRecall(ctx context.Context, notice recall.Notice) error {
+	locale := emailLocale(notice.Locale)
+	festivalID := notice.FestivalID
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       notice.To,
+		Subject:  i18n.T(locale, "email.recall.subject", i18n.Params{"festival": notice.FestivalName}),
+		Template: "product_recall",
+		TemplateData: map[string]interface{}{
+			"Status":         string(notice.Status),
+			"FestivalName":   notice.FestivalName,
+			"ProductName":    notice.ProductName,
+			"Reason":         notice.Reason,
+			"StandName":      notice.StandName,
+			"PurchasedAt":    i18n.FormatDateTime(locale, notice.PurchasedAt),
+			"RefundedAmount": i18n.FormatAmount(locale, notice.RefundedAmount, notice.CurrencyName),
+			"Year":           time.Now().Year(),
+		},
+		FestivalID: &festivalID,
+		Locale:     locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// NotifyMenuChange enqueues the email telling a stand owner that an organizer approved
+// or rejected a change to the menu of their stand
+func (q *EmailQueue) NotifyMenuChange(ctx context.Context, notice vendorportal.MenuChangeNotice) error {
+	locale := emailLocale(notice.Locale)
+	festivalID := notice.FestivalID
+	status := "rejected"
+	if notice.Approved {
+		status = "approved"
+	}
+	publishAt := ""
+	if notice.PublishAt != nil {
+		publishAt = i18n.FormatDateTime(locale, *notice.PublishAt)
+	}
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       notice.To,
+		Subject:  i18n.T(locale, "email.menu_change.subject."+status, i18n.Params{"stand": notice.StandName}),
+		Template: "menu_change",
+		TemplateData: map[string]interface{}{
+			"Status":       status,
+			"Action":       string(notice.Action),
+			"FestivalName": notice.FestivalName,
+			"StandName":    notice.StandName,
+			"ProductName":  notice.ProductName,
+			"PublishAt":    publishAt,
+			"Note":         notice.Note,
+			"Year":         time.Now().Year(),
+		},
+		FestivalID: &festivalID,
+		Locale:     locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// SendMagicLink enqueues the email with the sign-in link of an attendee
+func (q *EmailQueue) SendMagicLink(ctx context.Context, email magiclink.LinkEmail) error {
+	locale := emailLocale(email.Locale)
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       email.To,
+		Subject:  i18n.T(locale, "email.magic_link.subject"),
+		Template: "magic_link",
+		TemplateData: map[string]interface{}{
+			"Email":            email.To,
+			"URL":              email.URL,
+			"ExpiresInMinutes": int(email.ExpiresIn.Minutes()),
+			"Year":             time.Now().Year(),
+		},
+		Priority: "high",
+		Locale:   locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// NotifyRoleChange enqueues the email telling a user their role changed, or an
+// administrator that the role of another user changed
+func (q *EmailQueue) NotifyRoleChange(ctx context.Context, notice user.RoleChangeNotice) error {
+	locale := emailLocale(notice.Locale)
+	recipient := "user"
+	subject := i18n.T(locale, "email.role_change.subject.user")
+	if notice.Admin {
+		recipient = "admin"
+		subject = i18n.T(locale, "email.role_change.subject.admin", i18n.Params{"name": notice.UserName})
+	}
+	actorName := notice.ActorName
+	if actorName == "" {
+		actorName = i18n.T(locale, "email.role_change.someone")
+	}
+
+	task, err := NewSendEmailTask(&SendEmailPayload{
+		To:       notice.To,
+		Subject:  subject,
+		Template: "role_change",
+		TemplateData: map[string]interface{}{
+			"Recipient": recipient,
+			"UserName":  notice.UserName,
+			"UserEmail": notice.UserEmail,
+			"ActorName": actorName,
+			"OldRole":   i18n.T(locale, "email.role_change.role."+string(notice.OldRole)),
+			"NewRole":   i18n.T(locale, "email.role_change.role."+string(notice.NewRole)),
+			"Reason":    notice.Reason,
+			"ChangedAt": i18n.FormatDateTime(locale, notice.ChangedAt),
+			"Year":      time.Now().Year(),
+		},
+		Priority: "high",
+		Locale:   locale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
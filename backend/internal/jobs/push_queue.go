@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+)
+
+// PushQueue enqueues push notifications sent on behalf of the API to the push worker
+type PushQueue struct {
+	client *queue.Client
+}
+
+// NewPushQueue creates a new push queue
+func NewPushQueue(client *queue.Client) *PushQueue {
+	return &PushQueue{client: client}
+}
+
+// NotifyWalletActivity enqueues the push telling a wallet holder about a purchase or
+// top-up and the balance left
+func (q *PushQueue) NotifyWalletActivity(ctx context.Context, notice wallet.ActivityNotice) error {
+	locale := emailLocale(notice.Locale)
+	festivalID := notice.FestivalID
+	balance := i18n.FormatAmount(locale, notice.Balance, notice.CurrencyName)
+
+	body := i18n.T(locale, "push.wallet_activity.body", i18n.Params{"balance": balance})
+	if notice.StandName != "" {
+		body = i18n.T(locale, "push.wallet_activity.body_at", i18n.Params{"stand": notice.StandName, "balance": balance})
+	}
+
+	return q.enqueue(ctx, &SendPushNotificationPayload{
+		UserID: notice.UserID,
+		Title: i18n.T(locale, "push.wallet_activity.title."+string(notice.Type), i18n.Params{
+			"amount": i18n.FormatAmount(locale, notice.Amount, notice.CurrencyName),
+		}),
+		Body: body,
+		Data: map[string]interface{}{
+			"type":          "wallet_activity",
+			"transactionId": notice.TransactionID.String(),
+			"balance":       notice.Balance,
+		},
+		FestivalID: &festivalID,
+		Priority:   "high",
+	})
+}
+
+// NotifyActivityDigest enqueues the nightly push summarizing what a wallet holder spent
+// over the day, at which stands, and the balance left
+func (q *PushQueue) NotifyActivityDigest(ctx context.Context, digest wallet.ActivityDigest) error {
+	locale := emailLocale(digest.Locale)
+	festivalID := digest.FestivalID
+	params := i18n.Params{
+		"total":   i18n.FormatAmount(locale, digest.TotalSpent, digest.CurrencyName),
+		"balance": i18n.FormatAmount(locale, digest.Balance, digest.CurrencyName),
+	}
+
+	var stands []string
+	for _, stand := range digest.TopStands {
+		if stand.StandName != "" {
+			stands = append(stands, fmt.Sprintf("%s (%s)", stand.StandName, i18n.FormatAmount(locale, stand.Spent, digest.CurrencyName)))
+		}
+	}
+	body := i18n.T(locale, "push.wallet_digest.body_no_stands", params)
+	if len(stands) > 0 {
+		params["stands"] = strings.Join(stands, ", ")
+		body = i18n.T(locale, "push.wallet_digest.body", params)
+	}
+
+	return q.enqueue(ctx, &SendPushNotificationPayload{
+		UserID: digest.UserID,
+		Title:  i18n.T(locale, "push.wallet_digest.title", i18n.Params{"festival": digest.FestivalName}),
+		Body:   body,
+		Data: map[string]interface{}{
+			"type":       "wallet_digest",
+			"walletId":   digest.WalletID.String(),
+			"from":       digest.From.Format(time.RFC3339),
+			"to":         digest.To.Format(time.RFC3339),
+			"totalSpent": digest.TotalSpent,
+			"purchases":  digest.Purchases,
+			"balance":    digest.Balance,
+		},
+		FestivalID: &festivalID,
+		Priority:   "normal",
+	})
+}
+
+func (q *PushQueue) enqueue(ctx context.Context, payload *SendPushNotificationPayload) error {
+	task, err := NewSendPushNotificationTask(payload)
+	if err != nil {
+		return fmt.Errorf("failed to create push task: %w", err)
+	}
+
+	if _, err := q.client.EnqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue push: %w", err)
+	}
+	return nil
+}
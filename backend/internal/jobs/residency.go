@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/residency"
+)
+
+// ResidencyRouter picks the provider a festival's data is sent to under its data
+// residency policy; satisfied by residency.Service
+type ResidencyRouter interface {
+	Route(ctx context.Context, festivalID uuid.UUID, kind residency.Kind) (*residency.Provider, error)
+}
+
+// routeProvider returns the provider a festival's data of a kind must be sent to, nil
+// for the default provider when no router is set or the task is not for a festival.
+// A festival pinned to the EU without an EU provider fails the task without retry,
+// the data must not leave the EU.
+func routeProvider(ctx context.Context, router ResidencyRouter, festivalID *uuid.UUID, kind residency.Kind) (*residency.Provider, error) {
+	if router == nil || festivalID == nil || *festivalID == uuid.Nil {
+		return nil, nil
+	}
+
+	provider, err := router.Route(ctx, *festivalID, kind)
+	if errors.Is(err, residency.ErrProviderOutsideEU) {
+		return nil, fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to route festival data: %w", err)
+	}
+	return provider, nil
+}
@@ -3,10 +3,13 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/mimi6060/festivals/backend/internal/domain/residency"
+	"github.com/mimi6060/festivals/backend/internal/domain/suppression"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/queue"
 	"github.com/mimi6060/festivals/backend/internal/infrastructure/sms"
 	"github.com/rs/zerolog/log"
@@ -15,6 +18,8 @@ import (
 // SMSWorker handles SMS sending tasks
 type SMSWorker struct {
 	twilioClient *sms.TwilioClient
+	suppressor   DeliverySuppressor
+	residency    ResidencyRouter
 }
 
 // NewSMSWorker creates a new SMS worker
@@ -24,6 +29,17 @@ func NewSMSWorker(twilioClient *sms.TwilioClient) *SMSWorker {
 	}
 }
 
+// SetSuppressor sets the suppression list checked before each SMS is sent
+func (w *SMSWorker) SetSuppressor(suppressor DeliverySuppressor) {
+	w.suppressor = suppressor
+}
+
+// SetResidencyRouter sets the residency policies that keep the SMS of festivals pinned
+// to the EU from being sent through a provider outside the EU
+func (w *SMSWorker) SetResidencyRouter(router ResidencyRouter) {
+	w.residency = router
+}
+
 // RegisterHandlers registers all SMS task handlers
 func (w *SMSWorker) RegisterHandlers(server *queue.Server) {
 	server.HandleFunc(queue.TypeSendSMS, w.HandleSendSMS)
@@ -55,6 +71,18 @@ func (w *SMSWorker) HandleSendSMS(ctx context.Context, task *asynq.Task) error {
 		return nil // Don't fail if SMS is not configured
 	}
 
+	if _, err := routeProvider(ctx, w.residency, payload.FestivalID, residency.KindSMS); err != nil {
+		return err
+	}
+
+	if isSuppressed(ctx, w.suppressor, suppression.ChannelSMS, payload.To) {
+		log.Info().
+			Str("taskId", taskID).
+			Str("to", payload.To).
+			Msg("Number is on the suppression list, skipping SMS")
+		return nil
+	}
+
 	// Send SMS via Twilio
 	result, err := w.twilioClient.SendSMS(ctx, payload.To, payload.Message)
 	if err != nil {
@@ -71,6 +99,7 @@ func (w *SMSWorker) HandleSendSMS(ctx context.Context, task *asynq.Task) error {
 				Err(err).
 				Str("to", payload.To).
 				Msg("Permanent SMS failure, not retrying")
+			recordSMSFailure(ctx, w.suppressor, payload.To, err)
 			return nil // Don't retry permanent failures
 		}
 
@@ -110,10 +139,27 @@ func (w *SMSWorker) HandleSendBulkSMS(ctx context.Context, task *asynq.Task) err
 		return nil
 	}
 
-	// Extract phone numbers from recipients
-	phoneNumbers := make([]string, len(payload.Recipients))
-	for i, r := range payload.Recipients {
-		phoneNumbers[i] = r.PhoneNumber
+	if _, err := routeProvider(ctx, w.residency, &payload.FestivalID, residency.KindSMS); err != nil {
+		return err
+	}
+
+	// Extract phone numbers from recipients, leaving out suppressed numbers
+	phoneNumbers := make([]string, 0, len(payload.Recipients))
+	skipped := 0
+	for _, r := range payload.Recipients {
+		if isSuppressed(ctx, w.suppressor, suppression.ChannelSMS, r.PhoneNumber) {
+			skipped++
+			continue
+		}
+		phoneNumbers = append(phoneNumbers, r.PhoneNumber)
+	}
+
+	if len(phoneNumbers) == 0 {
+		log.Info().
+			Str("taskId", taskID).
+			Int("skipped", skipped).
+			Msg("All bulk SMS recipients are suppressed, nothing to send")
+		return nil
 	}
 
 	// Send bulk SMS with rate limiting
@@ -135,6 +181,7 @@ func (w *SMSWorker) HandleSendBulkSMS(ctx context.Context, task *asynq.Task) err
 		Str("taskId", taskID).
 		Int("totalSent", result.TotalSent).
 		Int("totalFailed", result.TotalFailed).
+		Int("skipped", skipped).
 		Int("total", len(payload.Recipients)).
 		Msg("Bulk SMS completed")
 
@@ -145,6 +192,7 @@ func (w *SMSWorker) HandleSendBulkSMS(ctx context.Context, task *asynq.Task) err
 			Str("number", number).
 			Str("error", errMsg).
 			Msg("Failed to send SMS to recipient")
+		recordSMSFailure(ctx, w.suppressor, number, errors.New(errMsg))
 	}
 
 	// Consider partial success as success (individual retries should be handled separately)
@@ -177,6 +225,18 @@ func (w *SMSWorker) HandleSendSMSNotification(ctx context.Context, task *asynq.T
 		return nil
 	}
 
+	if _, err := routeProvider(ctx, w.residency, payload.FestivalID, residency.KindSMS); err != nil {
+		return err
+	}
+
+	if isSuppressed(ctx, w.suppressor, suppression.ChannelSMS, payload.PhoneNumber) {
+		log.Info().
+			Str("taskId", taskID).
+			Str("phoneNumber", payload.PhoneNumber).
+			Msg("Number is on the suppression list, skipping SMS notification")
+		return nil
+	}
+
 	result, err := w.twilioClient.SendSMS(ctx, payload.PhoneNumber, payload.Message)
 	if err != nil {
 		if isPermanentSMSFailure(err) {
@@ -184,6 +244,7 @@ func (w *SMSWorker) HandleSendSMSNotification(ctx context.Context, task *asynq.T
 				Err(err).
 				Str("phoneNumber", payload.PhoneNumber).
 				Msg("Permanent SMS notification failure, not retrying")
+			recordSMSFailure(ctx, w.suppressor, payload.PhoneNumber, err)
 			return nil
 		}
 		return fmt.Errorf("failed to send SMS notification: %w", err)
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/suppression"
+	"github.com/rs/zerolog/log"
+)
+
+// DeliverySuppressor checks and records suppressed addresses; satisfied by suppression.Service
+type DeliverySuppressor interface {
+	IsSuppressed(ctx context.Context, channel suppression.Channel, address string) (bool, error)
+	RecordFailure(ctx context.Context, channel suppression.Channel, address string, reason suppression.Reason, details string) error
+}
+
+// isSuppressed reports whether delivery to address must be skipped. Lookup errors
+// are logged and the message is sent, a suppression list outage must not block delivery.
+func isSuppressed(ctx context.Context, suppressor DeliverySuppressor, channel suppression.Channel, address string) bool {
+	if suppressor == nil {
+		return false
+	}
+
+	suppressed, err := suppressor.IsSuppressed(ctx, channel, address)
+	if err != nil {
+		log.Warn().Err(err).Str("channel", string(channel)).Msg("Failed to check suppression list, sending anyway")
+		return false
+	}
+	return suppressed
+}
+
+// smsSuppressionReason maps a permanent Twilio failure to a suppression reason;
+// it returns false for failures that do not say anything about the number
+func smsSuppressionReason(err error) (suppression.Reason, bool) {
+	errMsg := err.Error()
+	if strings.Contains(errMsg, "21610") {
+		return suppression.ReasonUnsubscribed, true
+	}
+
+	invalidNumberErrors := []string{"21211", "21212", "21214", "21217", "21611", "30005", "30006"}
+	for _, code := range invalidNumberErrors {
+		if strings.Contains(errMsg, code) {
+			return suppression.ReasonHardBounce, true
+		}
+	}
+	return "", false
+}
+
+// recordSMSFailure adds a number to the suppression list after a permanent failure
+func recordSMSFailure(ctx context.Context, suppressor DeliverySuppressor, phoneNumber string, err error) {
+	if suppressor == nil {
+		return
+	}
+
+	reason, ok := smsSuppressionReason(err)
+	if !ok {
+		return
+	}
+	if recordErr := suppressor.RecordFailure(ctx, suppression.ChannelSMS, phoneNumber, reason, err.Error()); recordErr != nil {
+		log.Warn().Err(recordErr).Msg("Failed to record SMS delivery failure")
+	}
+}
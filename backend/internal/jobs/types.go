@@ -22,6 +22,7 @@ type SendEmailPayload struct {
 	Attachments []EmailAttachment `json:"attachments,omitempty"`
 	Priority    string            `json:"priority,omitempty"` // high, normal, low
 	FestivalID  *uuid.UUID        `json:"festivalId,omitempty"`
+	Locale      string            `json:"locale,omitempty"` // Language of the template text; en when empty
 }
 
 // EmailAttachment represents an email attachment
@@ -39,6 +40,7 @@ type SendWelcomeEmailPayload struct {
 	Name        string    `json:"name"`
 	FestivalID  *uuid.UUID `json:"festivalId,omitempty"`
 	FestivalName string   `json:"festivalName,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
 }
 
 // SendTicketEmailPayload represents the payload for sending ticket confirmation
@@ -54,6 +56,7 @@ type SendTicketEmailPayload struct {
 	QRCodeURL   string    `json:"qrCodeUrl,omitempty"`
 	EventDate   time.Time `json:"eventDate"`
 	Venue       string    `json:"venue,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
 }
 
 // SendRefundNotificationPayload represents the payload for refund notification email
@@ -70,6 +73,7 @@ type SendRefundNotificationPayload struct {
 	ProcessedAt    time.Time `json:"processedAt"`
 	FestivalID     uuid.UUID `json:"festivalId"`
 	FestivalName   string    `json:"festivalName"`
+	Locale         string    `json:"locale,omitempty"`
 }
 
 // ============================================================================
@@ -580,6 +584,12 @@ type AggregateAnalyticsPayload struct {
 	ReplaceExisting bool    `json:"replaceExisting"`
 }
 
+// RecomputeAnalyticsDaysPayload represents the payload for recomputing the daily
+// aggregates of a festival after its operational day boundary changed
+type RecomputeAnalyticsDaysPayload struct {
+	FestivalID uuid.UUID `json:"festivalId"`
+}
+
 // AnalyticsEventPayload represents a single analytics event to process
 type AnalyticsEventPayload struct {
 	EventID     uuid.UUID              `json:"eventId"`
@@ -623,6 +633,15 @@ func NewAggregateAnalyticsTask(payload *AggregateAnalyticsPayload) (*asynq.Task,
 	return asynq.NewTask(queue.TypeAggregateAnalytics, data, asynq.MaxRetry(2), asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)), nil
 }
 
+// NewRecomputeAnalyticsDaysTask creates a task for recomputing the daily aggregates of a festival
+func NewRecomputeAnalyticsDaysTask(payload *RecomputeAnalyticsDaysPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(queue.TypeRecomputeAnalyticsDays, data, asynq.MaxRetry(2), asynq.Queue(queue.QueueLow), asynq.Timeout(30*time.Minute)), nil
+}
+
 // NewProcessAnalyticsEventTask creates a task for processing a single analytics event
 func NewProcessAnalyticsEventTask(payload *AnalyticsEventPayload) (*asynq.Task, error) {
 	data, err := json.Marshal(payload)
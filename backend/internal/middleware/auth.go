@@ -19,6 +19,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 )
@@ -28,6 +30,7 @@ const (
 	RoleAdmin     = "ADMIN"
 	RoleOrganizer = "ORGANIZER"
 	RoleStaff     = "STAFF"
+	RoleVendor    = "VENDOR" // Stand owner, limited to the stands they own
 	RoleUser      = "USER"
 )
 
@@ -57,6 +60,10 @@ type Claims struct {
 	FestivalID string   `json:"https://festivals.app/festival_id"`
 	StandIDs   []string `json:"https://festivals.app/stand_ids"`
 	OrganizerFor []string `json:"https://festivals.app/organizer_for"`
+	// Authentication methods of the session and time of the last second factor, set
+	// by the Auth0 login Action to require a recent one for sensitive actions
+	AMR     []string `json:"https://festivals.app/amr,omitempty"`
+	MFATime int64    `json:"https://festivals.app/mfa_time,omitempty"`
 }
 
 // AuthConfig holds configuration for the Auth middleware
@@ -65,24 +72,43 @@ type AuthConfig struct {
 	Audiences    []string // Support multiple audiences
 	Issuer       string
 	CacheTTL     time.Duration
+	KeyOverlap   time.Duration // How long a signing key removed from the JWKS stays accepted
 	RedisClient  *redis.Client
 	Development  bool   // Skip verification in development - MUST be explicitly enabled via ALLOW_DEV_AUTH=true
 	Environment  string // Current environment (development, staging, production)
+	SessionKeys  *security.Keyring // Verifies the session tokens of SSO and magic link sign-ins; defaults to the keyring of SetSessionKeys
 }
 
+// jwksMinRefreshInterval limits how often tokens with an unknown kid can make the
+// cache fetch the JWKS from Auth0
+const jwksMinRefreshInterval = time.Minute
+
 // JWKSCache handles caching of JWKS keys
 type JWKSCache struct {
 	mu          sync.RWMutex
 	keys        jwk.Set
+	retired     map[string]retiredJWK // Keys removed from the JWKS, by kid
 	lastFetch   time.Time
+	lastSync    time.Time // Last fetch from Auth0 rather than Redis
 	ttl         time.Duration
+	keyOverlap  time.Duration
 	domain      string
 	redisClient *redis.Client
 }
 
+// retiredJWK is a signing key Auth0 no longer publishes that is still accepted
+type retiredJWK struct {
+	key         jwk.Key
+	acceptUntil time.Time
+}
+
 var (
 	jwksCache     *JWKSCache
 	jwksCacheLock sync.Mutex
+
+	// sessionKeys verifies the session tokens of SSO and magic link sign-ins when
+	// AuthConfig has none
+	sessionKeys *security.Keyring
 )
 
 // NewJWKSCache creates a new JWKS cache
@@ -91,9 +117,18 @@ func NewJWKSCache(domain string, ttl time.Duration, redisClient *redis.Client) *
 		domain:      domain,
 		ttl:         ttl,
 		redisClient: redisClient,
+		retired:     make(map[string]retiredJWK),
 	}
 }
 
+// SetKeyOverlap keeps keys removed from the JWKS accepted for the given window, so
+// tokens signed before an Auth0 key rotation stay valid. Zero rejects them right away.
+func (c *JWKSCache) SetKeyOverlap(overlap time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyOverlap = overlap
+}
+
 // GetKey retrieves a key from the JWKS cache
 func (c *JWKSCache) GetKey(kid string) (*rsa.PublicKey, error) {
 	c.mu.RLock()
@@ -103,7 +138,7 @@ func (c *JWKSCache) GetKey(kid string) (*rsa.PublicKey, error) {
 
 	// Check if cache needs refresh
 	if keys == nil || time.Since(lastFetch) > c.ttl {
-		if err := c.refresh(); err != nil {
+		if err := c.refresh(false); err != nil {
 			// If we have cached keys, use them even if refresh fails
 			if keys != nil {
 				log.Warn().Err(err).Msg("Failed to refresh JWKS, using cached keys")
@@ -111,22 +146,17 @@ func (c *JWKSCache) GetKey(kid string) (*rsa.PublicKey, error) {
 				return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
 			}
 		}
-		c.mu.RLock()
-		keys = c.keys
-		c.mu.RUnlock()
 	}
 
 	// Find key by kid
-	key, found := keys.LookupKeyID(kid)
+	key, found := c.lookup(kid)
 	if !found {
-		// Try refreshing in case new keys were added
-		if err := c.refresh(); err != nil {
+		// A new kid usually means Auth0 rotated its signing key, which the copy
+		// shared in Redis may not have yet, so go to Auth0 directly
+		if err := c.refresh(true); err != nil {
 			return nil, fmt.Errorf("key not found and refresh failed: %w", err)
 		}
-		c.mu.RLock()
-		keys = c.keys
-		c.mu.RUnlock()
-		key, found = keys.LookupKeyID(kid)
+		key, found = c.lookup(kid)
 		if !found {
 			return nil, fmt.Errorf("key with kid %s not found", kid)
 		}
@@ -146,41 +176,80 @@ func (c *JWKSCache) GetKey(kid string) (*rsa.PublicKey, error) {
 	return rsaKey, nil
 }
 
-// refresh fetches new keys from the JWKS endpoint
-func (c *JWKSCache) refresh() error {
+// lookup finds a published key, or a retired one still within its overlap window
+func (c *JWKSCache) lookup(kid string) (jwk.Key, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.keys != nil {
+		if key, found := c.keys.LookupKeyID(kid); found {
+			return key, true
+		}
+	}
+	if retired, found := c.retired[kid]; found && time.Now().Before(retired.acceptUntil) {
+		return retired.key, true
+	}
+	return nil, false
+}
+
+// refresh fetches new keys, from Redis when another instance fetched them recently.
+// A forced refresh skips Redis and fetches from Auth0 at most once per
+// jwksMinRefreshInterval.
+func (c *JWKSCache) refresh(force bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Double-check after acquiring lock
-	if c.keys != nil && time.Since(c.lastFetch) < c.ttl/2 {
-		return nil
-	}
+	if force {
+		if time.Since(c.lastSync) < jwksMinRefreshInterval {
+			return nil
+		}
+	} else {
+		// Double-check after acquiring lock
+		if c.keys != nil && time.Since(c.lastFetch) < c.ttl/2 {
+			return nil
+		}
 
-	// Try to get from Redis first (for distributed caching)
-	if c.redisClient != nil {
-		cached, err := c.redisClient.Get(context.Background(), "jwks:"+c.domain).Result()
-		if err == nil && cached != "" {
-			keys, err := jwk.Parse([]byte(cached))
-			if err == nil {
-				c.keys = keys
-				c.lastFetch = time.Now()
-				return nil
+		// Try to get from Redis first (for distributed caching)
+		if c.redisClient != nil {
+			cached, err := c.redisClient.Get(context.Background(), "jwks:"+c.domain).Result()
+			if err == nil && cached != "" {
+				keys, err := jwk.Parse([]byte(cached))
+				if err == nil {
+					c.setKeys(keys)
+					c.lastFetch = time.Now()
+					return nil
+				}
 			}
 		}
 	}
 
-	// Fetch from Auth0
+	_, _, err := c.fetch(context.Background())
+	return err
+}
+
+// Reload fetches the keys from Auth0 right away and returns the kids that were
+// added to and removed from the JWKS
+func (c *JWKSCache) Reload(ctx context.Context) ([]string, []string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fetch(ctx)
+}
+
+// fetch fetches the keys from the JWKS endpoint and shares them through Redis. The
+// caller holds the lock.
+func (c *JWKSCache) fetch(ctx context.Context) ([]string, []string, error) {
 	jwksURL := fmt.Sprintf("https://%s/.well-known/jwks.json", c.domain)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	keys, err := jwk.Fetch(ctx, jwksURL)
 	if err != nil {
-		return fmt.Errorf("failed to fetch JWKS from %s: %w", jwksURL, err)
+		return nil, nil, fmt.Errorf("failed to fetch JWKS from %s: %w", jwksURL, err)
 	}
 
-	c.keys = keys
+	added, removed := c.setKeys(keys)
 	c.lastFetch = time.Now()
+	c.lastSync = c.lastFetch
 
 	// Cache in Redis
 	if c.redisClient != nil {
@@ -188,8 +257,68 @@ func (c *JWKSCache) refresh() error {
 		c.redisClient.Set(context.Background(), "jwks:"+c.domain, string(jwksJSON), c.ttl)
 	}
 
-	log.Info().Str("domain", c.domain).Msg("JWKS cache refreshed")
-	return nil
+	log.Info().
+		Str("domain", c.domain).
+		Strs("added", added).
+		Strs("removed", removed).
+		Msg("JWKS cache refreshed")
+	return added, removed, nil
+}
+
+// setKeys replaces the cached key set, retiring the keys it no longer contains for
+// the overlap window. The caller holds the lock.
+func (c *JWKSCache) setKeys(keys jwk.Set) ([]string, []string) {
+	now := time.Now()
+	var added, removed []string
+
+	if c.keys != nil {
+		for i := 0; i < c.keys.Len(); i++ {
+			key, _ := c.keys.Key(i)
+			if _, found := keys.LookupKeyID(key.KeyID()); !found {
+				removed = append(removed, key.KeyID())
+				if c.keyOverlap > 0 {
+					c.retired[key.KeyID()] = retiredJWK{key: key, acceptUntil: now.Add(c.keyOverlap)}
+				}
+			}
+		}
+	}
+	for i := 0; i < keys.Len(); i++ {
+		key, _ := keys.Key(i)
+		if c.keys != nil {
+			if _, found := c.keys.LookupKeyID(key.KeyID()); !found {
+				added = append(added, key.KeyID())
+			}
+		}
+		delete(c.retired, key.KeyID())
+	}
+	for kid, retired := range c.retired {
+		if !now.Before(retired.acceptUntil) {
+			delete(c.retired, kid)
+		}
+	}
+
+	c.keys = keys
+	return added, removed
+}
+
+// ReloadJWKS fetches the signing keys of the auth middleware from Auth0 right away,
+// returning the kids added to and removed from the JWKS. It does nothing before the
+// middleware is set up.
+func ReloadJWKS(ctx context.Context) ([]string, []string, error) {
+	jwksCacheLock.Lock()
+	cache := jwksCache
+	jwksCacheLock.Unlock()
+
+	if cache == nil || cache.domain == "" {
+		return nil, nil, nil
+	}
+	return cache.Reload(ctx)
+}
+
+// SetSessionKeys makes the Auth middlewares accept the session tokens the API signs for
+// SSO and magic link sign-ins, next to Auth0 tokens. Call it at startup, before serving requests.
+func SetSessionKeys(keyring *security.Keyring) {
+	sessionKeys = keyring
 }
 
 // Auth creates the authentication middleware
@@ -203,6 +332,7 @@ func Auth(cfg AuthConfig) gin.HandlerFunc {
 		}
 		jwksCache = NewJWKSCache(cfg.Domain, cacheTTL, cfg.RedisClient)
 	}
+	jwksCache.SetKeyOverlap(cfg.KeyOverlap)
 	jwksCacheLock.Unlock()
 
 	// Build issuer URL
@@ -243,21 +373,34 @@ func Auth(cfg AuthConfig) gin.HandlerFunc {
 			return
 		}
 
+		// Session tokens of SSO and magic link sign-ins are signed by the API rather than Auth0
+		sessionToken := unverifiedToken.Claims.(*Claims).Issuer == security.SessionTokenIssuer
+
 		// Get kid from header
 		kid, ok := unverifiedToken.Header["kid"].(string)
-		if !ok && !cfg.Development {
+		if !ok && (!cfg.Development || sessionToken) {
 			respondUnauthorized(c, "Token missing key ID")
 			return
 		}
 
 		var token *jwt.Token
 
-		if devModeAllowed {
+		if sessionToken {
+			keys := cfg.SessionKeys
+			if keys == nil {
+				keys = sessionKeys
+			}
+			token, err = parseSessionToken(tokenString, keys)
+			if err != nil {
+				handleTokenError(c, err)
+				return
+			}
+		} else if devModeAllowed {
 			// Development mode: parse without signature verification
 			// SECURITY WARNING: This mode should ONLY be used in local development
 			log.Warn().
 				Str("environment", cfg.Environment).
-				Str("client_ip", c.ClientIP()).
+				Str("client_ip", privacy.LogIP(c.ClientIP())).
 				Msg("SECURITY WARNING: Processing request with development auth mode - signatures not verified")
 
 			parser := jwt.NewParser(
@@ -315,7 +458,7 @@ func Auth(cfg AuthConfig) gin.HandlerFunc {
 		}
 
 		// Validate audience (support multiple audiences)
-		if len(cfg.Audiences) > 0 && !devModeAllowed {
+		if len(cfg.Audiences) > 0 && !devModeAllowed && !sessionToken {
 			validAudience := false
 			for _, aud := range cfg.Audiences {
 				for _, tokenAud := range claims.Audience {
@@ -354,6 +497,8 @@ func Auth(cfg AuthConfig) gin.HandlerFunc {
 		c.Set("roles", claims.Roles)
 		c.Set("permissions", claims.Permissions)
 		c.Set("festival_id", claims.FestivalID)
+		c.Set("amr", claims.AMR)
+		c.Set("mfa_time", claims.MFATime)
 
 		c.Next()
 	}
@@ -379,14 +524,41 @@ func AuthWithSimpleConfig(auth0Domain, auth0Audience, environment string) gin.Ha
 			Msg("SECURITY WARNING: Auth middleware running in development mode - token signatures will NOT be verified")
 	}
 
+	// Overlap for Auth0 signing keys removed from the JWKS, none by default so that
+	// revoking a key in Auth0 takes effect immediately
+	keyOverlap, _ := time.ParseDuration(os.Getenv("AUTH0_JWKS_KEY_OVERLAP"))
+
 	return Auth(AuthConfig{
 		Domain:      auth0Domain,
 		Audiences:   audiences,
+		KeyOverlap:  keyOverlap,
 		Development: isDev,
 		Environment: environment,
 	})
 }
 
+// parseSessionToken verifies a session token signed by the API with a key derived from
+// one of the accepted keyring secrets
+func parseSessionToken(tokenString string, keys *security.Keyring) (*jwt.Token, error) {
+	if keys == nil {
+		return nil, errors.New("session tokens are not accepted")
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(security.SessionTokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	return parser.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, secret := range keys.Accepted() {
+			if security.Fingerprint(secret) == kid {
+				return security.SessionTokenKey(secret), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	})
+}
+
 // OptionalAuth is like Auth but doesn't fail if no token is provided
 func OptionalAuth(cfg AuthConfig) gin.HandlerFunc {
 	authMiddleware := Auth(cfg)
@@ -662,8 +834,16 @@ func (b *BruteForceProtector) CheckAndRecord(ctx context.Context, identifier str
 		// Progressive lockout: double duration for each subsequent lockout
 		if b.config.ProgressiveLockout {
 			lockoutCountKey := fmt.Sprintf("%slockout_count:%s:%s", b.config.KeyPrefix, keyType, identifier)
-			lockoutCount, _ := b.config.RedisClient.Incr(ctx, lockoutCountKey).Result()
-			b.config.RedisClient.Expire(ctx, lockoutCountKey, 24*time.Hour)
+			pipe := b.config.RedisClient.Pipeline()
+			incr := pipe.Incr(ctx, lockoutCountKey)
+			pipe.Expire(ctx, lockoutCountKey, 24*time.Hour)
+			if _, err := pipe.Exec(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to increment brute force lockout counter")
+			}
+			lockoutCount := incr.Val()
+			if lockoutCount < 1 {
+				lockoutCount = 1
+			}
 
 			// Cap at 24 hours
 			multiplier := int64(1) << (lockoutCount - 1) // 2^(n-1)
@@ -673,9 +853,13 @@ func (b *BruteForceProtector) CheckAndRecord(ctx context.Context, identifier str
 			lockoutDuration = time.Duration(multiplier) * b.config.LockoutDuration
 		}
 
-		// Set lockout
-		b.config.RedisClient.Set(ctx, lockoutKey, "1", lockoutDuration)
-		b.config.RedisClient.Del(ctx, attemptsKey)
+		// Set lockout and reset attempts in one round trip
+		pipe := b.config.RedisClient.TxPipeline()
+		pipe.Set(ctx, lockoutKey, "1", lockoutDuration)
+		pipe.Del(ctx, attemptsKey)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to store brute force lockout")
+		}
 
 		log.Warn().
 			Str("identifier", identifier).
@@ -838,18 +1022,19 @@ func (s *SessionManager) CreateSession(ctx context.Context, userID, ipAddress, u
 		Data:         make(map[string]string),
 	}
 
-	// Store session
+	// Store session and add it to the user's session list in one round trip
 	sessionKey := s.config.KeyPrefix + sessionID
+	userSessionsKey := s.config.KeyPrefix + "user:" + userID
 	data, _ := json.Marshal(session)
-	if err := s.config.RedisClient.Set(ctx, sessionKey, data, s.config.SessionDuration).Err(); err != nil {
+
+	pipe := s.config.RedisClient.TxPipeline()
+	pipe.Set(ctx, sessionKey, data, s.config.SessionDuration)
+	pipe.SAdd(ctx, userSessionsKey, sessionID)
+	pipe.Expire(ctx, userSessionsKey, s.config.SessionDuration)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("failed to store session: %w", err)
 	}
 
-	// Add to user's session list
-	userSessionsKey := s.config.KeyPrefix + "user:" + userID
-	s.config.RedisClient.SAdd(ctx, userSessionsKey, sessionID)
-	s.config.RedisClient.Expire(ctx, userSessionsKey, s.config.SessionDuration)
-
 	// Enforce max sessions
 	if s.config.MaxSessions > 0 {
 		s.enforceMaxSessions(ctx, userID)
@@ -927,26 +1112,27 @@ func (s *SessionManager) RotateSession(ctx context.Context, oldSessionID string)
 		return nil, fmt.Errorf("failed to generate new session ID: %w", err)
 	}
 
-	// Update session with new ID
+	// Update session with new ID, the CSRF token is issued again for the new session
 	oldID := session.ID
 	session.ID = newSessionID
+	delete(session.Data, csrfSessionKey)
 
-	// Store new session
+	// Store the new session, swap it in the user's session list and delete the old
+	// one atomically
 	newSessionKey := s.config.KeyPrefix + newSessionID
+	oldSessionKey := s.config.KeyPrefix + oldID
+	userSessionsKey := s.config.KeyPrefix + "user:" + session.UserID
 	data, _ := json.Marshal(session)
-	if err := s.config.RedisClient.Set(ctx, newSessionKey, data, s.config.SessionDuration).Err(); err != nil {
+
+	pipe := s.config.RedisClient.TxPipeline()
+	pipe.Set(ctx, newSessionKey, data, s.config.SessionDuration)
+	pipe.SRem(ctx, userSessionsKey, oldID)
+	pipe.SAdd(ctx, userSessionsKey, newSessionID)
+	pipe.Del(ctx, oldSessionKey)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("failed to store rotated session: %w", err)
 	}
 
-	// Update user's session list
-	userSessionsKey := s.config.KeyPrefix + "user:" + session.UserID
-	s.config.RedisClient.SRem(ctx, userSessionsKey, oldID)
-	s.config.RedisClient.SAdd(ctx, userSessionsKey, newSessionID)
-
-	// Delete old session
-	oldSessionKey := s.config.KeyPrefix + oldID
-	s.config.RedisClient.Del(ctx, oldSessionKey)
-
 	log.Info().
 		Str("user_id", session.UserID).
 		Str("old_session", oldID[:8]+"...").
@@ -967,13 +1153,15 @@ func (s *SessionManager) DestroySession(ctx context.Context, sessionID string) e
 		return nil
 	}
 
-	// Remove from user's session list
+	// Remove from user's session list and delete session
 	userSessionsKey := s.config.KeyPrefix + "user:" + session.UserID
-	s.config.RedisClient.SRem(ctx, userSessionsKey, sessionID)
-
-	// Delete session
 	sessionKey := s.config.KeyPrefix + sessionID
-	return s.config.RedisClient.Del(ctx, sessionKey).Err()
+
+	pipe := s.config.RedisClient.TxPipeline()
+	pipe.SRem(ctx, userSessionsKey, sessionID)
+	pipe.Del(ctx, sessionKey)
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
 // DestroyAllUserSessions destroys all sessions for a user
@@ -988,12 +1176,14 @@ func (s *SessionManager) DestroyAllUserSessions(ctx context.Context, userID stri
 		return err
 	}
 
+	// Delete every session and the list with a single command
+	keys := make([]string, 0, len(sessionIDs)+1)
 	for _, sessionID := range sessionIDs {
-		sessionKey := s.config.KeyPrefix + sessionID
-		s.config.RedisClient.Del(ctx, sessionKey)
+		keys = append(keys, s.config.KeyPrefix+sessionID)
 	}
+	keys = append(keys, userSessionsKey)
 
-	return s.config.RedisClient.Del(ctx, userSessionsKey).Err()
+	return s.config.RedisClient.Del(ctx, keys...).Err()
 }
 
 // enforceMaxSessions removes oldest sessions if limit exceeded
@@ -1010,15 +1200,31 @@ func (s *SessionManager) enforceMaxSessions(ctx context.Context, userID string)
 		CreatedAt time.Time
 	}
 	var sessions []sessionInfo
+	var expired []interface{}
 
-	for _, sessionID := range sessionIDs {
-		session, err := s.GetSession(ctx, sessionID)
-		if err != nil || session == nil {
-			// Remove invalid session
-			s.config.RedisClient.SRem(ctx, userSessionsKey, sessionID)
+	// Load all sessions with a single MGET instead of one GET per session
+	keys := make([]string, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		keys[i] = s.config.KeyPrefix + sessionID
+	}
+	values, err := s.config.RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return
+	}
+
+	for i, value := range values {
+		raw, ok := value.(string)
+		var session SessionData
+		if !ok || json.Unmarshal([]byte(raw), &session) != nil {
+			expired = append(expired, sessionIDs[i])
 			continue
 		}
-		sessions = append(sessions, sessionInfo{ID: sessionID, CreatedAt: session.CreatedAt})
+		sessions = append(sessions, sessionInfo{ID: sessionIDs[i], CreatedAt: session.CreatedAt})
+	}
+
+	// Remove invalid sessions
+	if len(expired) > 0 {
+		s.config.RedisClient.SRem(ctx, userSessionsKey, expired...)
 	}
 
 	// Sort by creation time (oldest first)
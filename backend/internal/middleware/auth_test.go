@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBruteForceProtector_ProgressiveLockout(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+
+	cfg := DefaultBruteForceConfig()
+	cfg.RedisClient = client
+	cfg.MaxAttempts = 3
+	cfg.LockoutDuration = time.Minute
+	protector := NewBruteForceProtector(cfg)
+
+	fail := func() (bool, time.Duration) {
+		allowed, lockout, err := protector.CheckAndRecord(ctx, "alice@example.com", false)
+		require.NoError(t, err)
+		return allowed, lockout
+	}
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := fail()
+		require.True(t, allowed)
+	}
+	allowed, lockout := fail()
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, lockout)
+
+	// Lockout stored and attempts reset by the transaction
+	assert.Equal(t, time.Minute, server.TTL("bruteforce:lockout:user:alice@example.com"))
+	assert.False(t, server.Exists("bruteforce:attempts:user:alice@example.com"))
+	assert.Equal(t, "1", mustGet(t, server, "bruteforce:lockout_count:user:alice@example.com"))
+	assert.Equal(t, 24*time.Hour, server.TTL("bruteforce:lockout_count:user:alice@example.com"))
+
+	locked, _ := protector.IsLocked(ctx, "alice@example.com", false)
+	assert.True(t, locked)
+
+	// The next lockout lasts twice as long
+	server.FastForward(time.Minute + time.Second)
+	locked, _ = protector.IsLocked(ctx, "alice@example.com", false)
+	require.False(t, locked)
+	for i := 0; i < 2; i++ {
+		allowed, _ := fail()
+		require.True(t, allowed)
+	}
+	_, lockout = fail()
+	assert.Equal(t, 2*time.Minute, lockout)
+
+	// A successful login forgets the previous lockouts
+	protector.RecordSuccess(ctx, "alice@example.com", false)
+	assert.False(t, server.Exists("bruteforce:lockout_count:user:alice@example.com"))
+}
+
+func TestSessionManager(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+
+	cfg := DefaultSessionConfig()
+	cfg.RedisClient = client
+	cfg.MaxSessions = 2
+	manager := NewSessionManager(cfg)
+	userSessions := "session:user:alice"
+
+	create := func() *SessionData {
+		session, err := manager.CreateSession(ctx, "alice", "192.0.2.1", "Mozilla/5.0")
+		require.NoError(t, err)
+		return session
+	}
+
+	first := create()
+	assert.Equal(t, 24*time.Hour, server.TTL("session:"+first.ID))
+	assert.Equal(t, 24*time.Hour, server.TTL(userSessions))
+	members, err := server.Members(userSessions)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID}, members)
+
+	t.Run("oldest session evicted above the limit", func(t *testing.T) {
+		second := create()
+		third := create()
+
+		members, err := server.Members(userSessions)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{second.ID, third.ID}, members)
+		assert.False(t, server.Exists("session:"+first.ID))
+	})
+
+	t.Run("expired sessions dropped from the list", func(t *testing.T) {
+		members, err := server.Members(userSessions)
+		require.NoError(t, err)
+		server.Del("session:" + members[0])
+		create()
+
+		members, err = server.Members(userSessions)
+		require.NoError(t, err)
+		assert.Len(t, members, 2)
+		for _, id := range members {
+			assert.True(t, server.Exists("session:"+id), id)
+		}
+	})
+
+	t.Run("rotation swaps the session", func(t *testing.T) {
+		members, err := server.Members(userSessions)
+		require.NoError(t, err)
+		oldID := members[0]
+
+		rotated, err := manager.RotateSession(ctx, oldID)
+		require.NoError(t, err)
+		assert.NotEqual(t, oldID, rotated.ID)
+		assert.False(t, server.Exists("session:"+oldID))
+		assert.True(t, server.Exists("session:"+rotated.ID))
+
+		members, err = server.Members(userSessions)
+		require.NoError(t, err)
+		assert.Contains(t, members, rotated.ID)
+		assert.NotContains(t, members, oldID)
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		members, err := server.Members(userSessions)
+		require.NoError(t, err)
+		require.NoError(t, manager.DestroySession(ctx, members[0]))
+		assert.False(t, server.Exists("session:"+members[0]))
+
+		require.NoError(t, manager.DestroyAllUserSessions(ctx, "alice"))
+		assert.False(t, server.Exists("session:"+members[1]))
+		assert.False(t, server.Exists(userSessions))
+	})
+}
@@ -1,17 +1,34 @@
 package middleware
 
 import (
+	"context"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
+// FestivalOriginPolicy decides the browser origins allowed on the routes of a festival
+// on top of the platform origins, and records the rejected ones
+type FestivalOriginPolicy interface {
+	// AllowOrigin is given the festival ID or slug found in the path, empty outside the
+	// festival routes
+	AllowOrigin(ctx context.Context, festival, origin, ip, method, path string) bool
+}
+
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
 	AllowedOrigins   []string
 	AllowedMethods   []string
 	AllowedHeaders   []string
 	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+	// FestivalOrigins allows more origins on the routes of each festival, e.g. the
+	// festival web apps hosted on their own domains
+	FestivalOrigins FestivalOriginPolicy
 }
 
 // DefaultCORSConfig returns default CORS configuration
@@ -33,6 +50,7 @@ func DefaultCORSConfig() CORSConfig {
 			"X-Request-ID",
 		},
 		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
 	}
 }
 
@@ -43,7 +61,8 @@ func CORS() gin.HandlerFunc {
 	return CORSWithConfig(DefaultCORSConfig())
 }
 
-// CORSWithConfig creates a CORS middleware with custom configuration
+// CORSWithConfig creates a CORS middleware with custom configuration. Origins not
+// allowed get no CORS headers, so the browser blocks the response.
 func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
 	// Build allowed methods string
 	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
@@ -57,6 +76,11 @@ func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
 		allowedHeaders = "Content-Type, Authorization, Accept, Origin, X-Request-ID"
 	}
 
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+
 	// Build origin lookup map for O(1) checks
 	allowedOriginMap := make(map[string]bool)
 	for _, origin := range cfg.AllowedOrigins {
@@ -65,23 +89,32 @@ func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
+		preflight := c.Request.Method == "OPTIONS"
 
-		// Check if origin is allowed
 		if origin != "" {
-			originLower := strings.ToLower(origin)
-			if allowedOriginMap[originLower] {
+			// The response depends on the origin, shared caches must not mix them up
+			c.Writer.Header().Add("Vary", "Origin")
+
+			allowed := allowedOriginMap[strings.ToLower(origin)]
+			if !allowed && cfg.FestivalOrigins != nil {
+				allowed = cfg.FestivalOrigins.AllowOrigin(c.Request.Context(), festivalFromPath(c.Request.URL.Path),
+					origin, c.ClientIP(), c.Request.Method, c.Request.URL.Path)
+			}
+
+			if allowed {
 				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 				if cfg.AllowCredentials {
 					c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 				}
+				c.Writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+				c.Writer.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+				if preflight && maxAge != "" {
+					c.Writer.Header().Set("Access-Control-Max-Age", maxAge)
+				}
 			}
-			// If origin is not allowed, don't set CORS headers (browser will block)
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
-		c.Writer.Header().Set("Access-Control-Allow-Methods", allowedMethods)
-
-		if c.Request.Method == "OPTIONS" {
+		if preflight {
 			c.AbortWithStatus(204)
 			return
 		}
@@ -90,17 +123,39 @@ func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
 	}
 }
 
+// festivalFromPath returns the festival ID or slug following the festivals segment of a
+// path, such as /api/v1/festivals/{id}/stands. The CORS middleware runs before routing,
+// and preflights have no route to take the festival from.
+func festivalFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == "festivals" {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
 // CORSForEnvironment returns appropriate CORS middleware based on environment
 func CORSForEnvironment(environment string, allowedOrigins []string) gin.HandlerFunc {
+	return CORSWithConfig(CORSConfigForEnvironment(environment, allowedOrigins))
+}
+
+// CORSConfigForEnvironment returns the CORS configuration of an environment. Production
+// only allows the configured https origins, dropping the others.
+func CORSConfigForEnvironment(environment string, allowedOrigins []string) CORSConfig {
 	cfg := DefaultCORSConfig()
 
 	if environment == "production" {
-		// In production, use only explicitly configured origins
-		if len(allowedOrigins) > 0 {
-			cfg.AllowedOrigins = allowedOrigins
-		} else {
-			// Fallback to empty list - blocks all cross-origin requests if not configured
-			cfg.AllowedOrigins = []string{}
+		// In production, use only explicitly configured https origins. Without any,
+		// all cross-origin requests are blocked.
+		cfg.AllowedOrigins = []string{}
+		for _, origin := range allowedOrigins {
+			if !strings.HasPrefix(strings.ToLower(origin), "https://") {
+				log.Warn().Str("origin", origin).Msg("Ignoring CORS origin not using https in production")
+				continue
+			}
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
 		}
 	} else {
 		// In development, use configured origins or defaults
@@ -110,5 +165,5 @@ func CORSForEnvironment(environment string, allowedOrigins []string) gin.Handler
 		// Default dev origins are already set in DefaultCORSConfig
 	}
 
-	return CORSWithConfig(cfg)
+	return cfg
 }
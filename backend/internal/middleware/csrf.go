@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
+	"github.com/mimi6060/festivals/backend/internal/pkg/security"
+)
+
+// csrfSessionKey is the session data entry holding the CSRF token of the session
+const csrfSessionKey = "csrf_token"
+
+// SessionCSRFConfig holds configuration for the CSRF protection of cookie sessions
+type SessionCSRFConfig struct {
+	Sessions   *SessionManager
+	HeaderName string
+
+	// Paths never checked, e.g. the webhooks signed by their provider
+	ExemptPaths []string
+
+	// Security auditor recording the rejected requests
+	Auditor *security.SecurityAuditor
+}
+
+// DefaultSessionCSRFConfig returns default CSRF configuration for the given sessions
+func DefaultSessionCSRFConfig(sessions *SessionManager) SessionCSRFConfig {
+	return SessionCSRFConfig{
+		Sessions:    sessions,
+		HeaderName:  "X-CSRF-Token",
+		ExemptPaths: []string{"/api/v1/webhooks"},
+	}
+}
+
+// SessionCSRF protects the requests authenticated by a session cookie with the
+// synchronizer token pattern: state-changing requests carrying the cookie must send
+// the token of their session in a header, which another site cannot read nor set.
+// Requests with a Bearer token and requests without a session cookie carry no
+// ambient credential and are not checked, so API clients are not affected.
+func SessionCSRF(cfg SessionCSRFConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requiresCSRFCheck(c, cfg) {
+			c.Next()
+			return
+		}
+
+		sessionID, err := c.Cookie(cfg.Sessions.CookieName())
+		if err != nil || sessionID == "" {
+			c.Next()
+			return
+		}
+
+		session, err := cfg.Sessions.GetSession(c.Request.Context(), sessionID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to load session for CSRF check")
+			respondAPIError(c, http.StatusServiceUnavailable, "CSRF_UNAVAILABLE", "Unable to verify the request, try again")
+			return
+		}
+		if session == nil {
+			// Unknown or expired session, it authenticates nothing
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader(cfg.HeaderName)
+		expected := session.Data[csrfSessionKey]
+		switch {
+		case token == "":
+			rejectCSRF(c, cfg, session, "missing")
+			respondSecurityError(c, "CSRF_MISSING", "CSRF token required")
+			return
+		case expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1:
+			rejectCSRF(c, cfg, session, "mismatch")
+			respondSecurityError(c, "CSRF_INVALID", "Invalid CSRF token")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CSRFTokenHandler returns the CSRF token of the session cookie, issuing it on the
+// first call. The dashboard sends it back in the CSRF header on state-changing requests.
+func CSRFTokenHandler(cfg SessionCSRFConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID, err := c.Cookie(cfg.Sessions.CookieName())
+		if err != nil || sessionID == "" {
+			respondUnauthorized(c, "Session cookie required")
+			return
+		}
+
+		token, err := cfg.Sessions.CSRFToken(c.Request.Context(), sessionID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to issue CSRF token")
+			respondAPIError(c, http.StatusInternalServerError, "CSRF_ERROR", "Failed to generate security token")
+			return
+		}
+		if token == "" {
+			respondUnauthorized(c, "Session expired")
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"token":      token,
+				"headerName": cfg.HeaderName,
+			},
+		})
+	}
+}
+
+// requiresCSRFCheck tells whether a request may carry an ambient session credential
+// that a cross-site request could abuse
+func requiresCSRFCheck(c *gin.Context, cfg SessionCSRFConfig) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	if strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+		return false
+	}
+
+	for _, path := range cfg.ExemptPaths {
+		if strings.HasPrefix(c.Request.URL.Path, path) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rejectCSRF logs and audits a request rejected for its CSRF token
+func rejectCSRF(c *gin.Context, cfg SessionCSRFConfig, session *SessionData, reason string) {
+	log.Warn().
+		Str("reason", reason).
+		Str("path", c.Request.URL.Path).
+		Str("method", c.Request.Method).
+		Str("origin", c.GetHeader("Origin")).
+		Str("ip", privacy.LogIP(c.ClientIP())).
+		Msg("CSRF check failed")
+
+	if cfg.Auditor == nil {
+		return
+	}
+
+	cfg.Auditor.LogEvent(c.Request.Context(), &security.SecurityEvent{
+		Type:      security.EventAttackCSRF,
+		Severity:  security.SeverityWarning,
+		UserID:    session.UserID,
+		SessionID: session.ID[:8] + "...",
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		Resource:  c.Request.URL.Path,
+		Action:    c.Request.Method,
+		Result:    "blocked",
+		Details: map[string]interface{}{
+			"reason":  reason,
+			"origin":  c.GetHeader("Origin"),
+			"referer": c.GetHeader("Referer"),
+		},
+	})
+}
+
+// CookieName returns the name of the session cookie
+func (s *SessionManager) CookieName() string {
+	return s.config.CookieName
+}
+
+// CSRFToken returns the CSRF token of a session, issuing one when the session has
+// none yet. It returns an empty token when the session does not exist.
+func (s *SessionManager) CSRFToken(ctx context.Context, sessionID string) (string, error) {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil || session == nil {
+		return "", err
+	}
+	if token := session.Data[csrfSessionKey]; token != "" {
+		return token, nil
+	}
+
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+	if session.Data == nil {
+		session.Data = make(map[string]string)
+	}
+	session.Data[csrfSessionKey] = token
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := s.config.RedisClient.Set(ctx, s.config.KeyPrefix+sessionID, data, redis.KeepTTL).Err(); err != nil {
+		return "", fmt.Errorf("failed to store CSRF token: %w", err)
+	}
+
+	return token, nil
+}
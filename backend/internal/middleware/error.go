@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mimi6060/festivals/backend/internal/pkg/errors"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/rs/zerolog/log"
 )
 
@@ -152,7 +153,7 @@ func logError(c *gin.Context, err *errors.AppError) {
 		Str("error_message", err.Message).
 		Str("method", c.Request.Method).
 		Str("path", c.Request.URL.Path).
-		Str("client_ip", c.ClientIP()).
+		Str("client_ip", privacy.LogIP(c.ClientIP())).
 		Int("status", err.HTTPStatus())
 
 	// Add user ID if available
@@ -196,7 +197,7 @@ func logPanic(c *gin.Context, recovered interface{}, stack string) {
 		Str("request_id", requestID).
 		Str("method", c.Request.Method).
 		Str("path", c.Request.URL.Path).
-		Str("client_ip", c.ClientIP()).
+		Str("client_ip", privacy.LogIP(c.ClientIP())).
 		Str("user_agent", c.Request.UserAgent()).
 		Interface("panic_value", recovered).
 		Str("stack_trace", stack).
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mimi6060/festivals/backend/internal/pkg/geoip"
+)
+
+// GeoAccessPolicy decides the access of client IPs from their location
+type GeoAccessPolicy interface {
+	Check(ctx context.Context, ip, method, path string) (geoip.Location, bool)
+}
+
+// GeoAccess resolves the country and AS of the client IP into "geo_location" and
+// rejects the requests the policy denies, e.g. admin routes from outside the EU
+func GeoAccess(policy GeoAccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		location, allowed := policy.Check(c.Request.Context(), c.ClientIP(), c.Request.Method, c.Request.URL.Path)
+		c.Set("geo_location", location)
+		if !allowed {
+			respondAPIError(c, http.StatusForbidden, "GEO_RESTRICTED", "Access is not allowed from your location")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/mimi6060/festivals/backend/internal/pkg/security"
 )
 
@@ -430,7 +431,7 @@ func handleInjectionDetected(c *gin.Context, cfg InjectionConfig, injType Inject
 			Str("pattern", pattern).
 			Str("path", c.Request.URL.Path).
 			Str("method", c.Request.Method).
-			Str("ip", c.ClientIP()).
+			Str("ip", privacy.LogIP(c.ClientIP())).
 			Str("user_agent", c.GetHeader("User-Agent")).
 			Msg("Injection attack detected")
 	}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// IPBlockList tells which client IPs are blocked
+type IPBlockList interface {
+	IsBlocked(ctx context.Context, ip string) (bool, error)
+}
+
+// BlockIPs rejects the requests of blocked client IPs, e.g. the scanners caught by the
+// honeypot. Requests are let through when the block list cannot be read, so an outage
+// of its store does not take the API down.
+func BlockIPs(list IPBlockList) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		blocked, err := list.IsBlocked(c.Request.Context(), ip)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to check IP block list")
+			c.Next()
+			return
+		}
+		if blocked {
+			respondForbidden(c, "Access denied")
+			return
+		}
+
+		c.Next()
+	}
+}
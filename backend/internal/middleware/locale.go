@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// userLocaleTTL bounds how long a changed language preference takes to apply to API responses
+const userLocaleTTL = time.Minute
+
+// Locale resolves the request locale from the Accept-Language header and makes it
+// available to handlers (c.GetString("locale")) and services (i18n.FromContext)
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLocale(c, i18n.Resolve("", c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// UserLocale overrides the request locale with the preferred language saved in the
+// authenticated user's notification preferences; must run after authentication
+func UserLocale(db *gorm.DB) gin.HandlerFunc {
+	var cache sync.Map // user ID -> cachedLocale
+
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.Next()
+			return
+		}
+
+		preferred, ok := "", false
+		if cached, found := cache.Load(userID); found && time.Now().Before(cached.(cachedLocale).expiresAt) {
+			preferred, ok = cached.(cachedLocale).locale, true
+		}
+
+		if !ok {
+			var languages []string
+			err := db.WithContext(c.Request.Context()).
+				Table("user_notification_preferences AS p").
+				Select("p.preferred_language").
+				Joins("JOIN users u ON u.id = p.user_id").
+				Where("u.auth0_id = ? OR u.id::text = ?", userID, userID).
+				Limit(1).
+				Scan(&languages).Error
+			if err != nil {
+				log.Warn().Err(err).Str("user_id", userID).Msg("Failed to load preferred language")
+			} else {
+				if len(languages) > 0 {
+					preferred = languages[0]
+				}
+				cache.Store(userID, cachedLocale{locale: preferred, expiresAt: time.Now().Add(userLocaleTTL)})
+			}
+		}
+
+		if preferred != "" {
+			setLocale(c, i18n.Resolve(preferred, c.GetHeader("Accept-Language")))
+		}
+
+		c.Next()
+	}
+}
+
+type cachedLocale struct {
+	locale    string
+	expiresAt time.Time
+}
+
+func setLocale(c *gin.Context, locale string) {
+	c.Set("locale", locale)
+	c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", locale)
+}
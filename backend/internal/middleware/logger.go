@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/rs/zerolog/log"
 )
 
@@ -29,7 +30,7 @@ func Logger() gin.HandlerFunc {
 			Str("query", query).
 			Int("status", status).
 			Dur("latency", latency).
-			Str("ip", c.ClientIP()).
+			Str("ip", privacy.LogIP(c.ClientIP())).
 			Str("user-agent", c.Request.UserAgent()).
 			Str("request-id", c.GetString("request_id")).
 			Msg("Request")
@@ -446,41 +446,42 @@ func BurstRateLimit(redisClient *redis.Client, ratePerSecond int, burstSize int)
 	}
 }
 
+// tokenBucketScript atomically takes a token from a bucket. It is parsed once so
+// requests only send EVALSHA.
+var tokenBucketScript = redis.NewScript(`
+	local tokens_key = KEYS[1]
+	local last_key = KEYS[2]
+	local rate = tonumber(ARGV[1])
+	local burst = tonumber(ARGV[2])
+	local now = tonumber(ARGV[3])
+	local ttl = tonumber(ARGV[4])
+
+	local last = tonumber(redis.call('get', last_key)) or now
+	local tokens = tonumber(redis.call('get', tokens_key)) or burst
+
+	-- Calculate new tokens based on time elapsed
+	local elapsed = now - last
+	tokens = math.min(burst, tokens + (elapsed * rate / 1000000000))
+
+	local allowed = 0
+	if tokens >= 1 then
+		tokens = tokens - 1
+		allowed = 1
+	end
+
+	redis.call('set', tokens_key, tokens, 'EX', ttl)
+	redis.call('set', last_key, now, 'EX', ttl)
+
+	return {allowed, math.floor(tokens)}
+`)
+
 // checkTokenBucket implements token bucket rate limiting
 func checkTokenBucket(ctx context.Context, client *redis.Client, key string, rate int, burst int) (bool, *RateLimitInfo, error) {
 	now := time.Now()
 	tokensKey := key + ":tokens"
 	lastKey := key + ":last"
 
-	// Lua script for atomic token bucket operation
-	script := redis.NewScript(`
-		local tokens_key = KEYS[1]
-		local last_key = KEYS[2]
-		local rate = tonumber(ARGV[1])
-		local burst = tonumber(ARGV[2])
-		local now = tonumber(ARGV[3])
-		local ttl = tonumber(ARGV[4])
-
-		local last = tonumber(redis.call('get', last_key)) or now
-		local tokens = tonumber(redis.call('get', tokens_key)) or burst
-
-		-- Calculate new tokens based on time elapsed
-		local elapsed = now - last
-		tokens = math.min(burst, tokens + (elapsed * rate / 1000000000))
-
-		local allowed = 0
-		if tokens >= 1 then
-			tokens = tokens - 1
-			allowed = 1
-		end
-
-		redis.call('set', tokens_key, tokens, 'EX', ttl)
-		redis.call('set', last_key, now, 'EX', ttl)
-
-		return {allowed, math.floor(tokens)}
-	`)
-
-	result, err := script.Run(ctx, client, []string{tokensKey, lastKey},
+	result, err := tokenBucketScript.Run(ctx, client, []string{tokensKey, lastKey},
 		rate, burst, now.UnixNano(), 60).Slice()
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to run token bucket script: %w", err)
@@ -517,16 +518,16 @@ func ConcurrencyLimit(redisClient *redis.Client, maxConcurrent int) gin.HandlerF
 
 		ctx := c.Request.Context()
 
-		// Try to increment the counter
-		current, err := redisClient.Incr(ctx, key).Result()
-		if err != nil {
+		// Increment the counter and set expiry to prevent stuck counters in one round trip
+		pipe := redisClient.Pipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, 5*time.Minute)
+		if _, err := pipe.Exec(ctx); err != nil {
 			log.Error().Err(err).Str("key", key).Msg("Concurrency limit check failed")
 			c.Next()
 			return
 		}
-
-		// Set expiry to prevent stuck counters
-		redisClient.Expire(ctx, key, 5*time.Minute)
+		current := incr.Val()
 
 		if current > int64(maxConcurrent) {
 			// Decrement since we're rejecting
@@ -1112,43 +1113,43 @@ func CostBasedRateLimit(cfg CostBasedRateLimitConfig) gin.HandlerFunc {
 	}
 }
 
+// costBasedLimitScript atomically takes cost tokens from a bucket refilled per minute
+var costBasedLimitScript = redis.NewScript(`
+	local tokens_key = KEYS[1]
+	local last_key = KEYS[2]
+	local rate = tonumber(ARGV[1])
+	local max_tokens = tonumber(ARGV[2])
+	local now = tonumber(ARGV[3])
+	local cost = tonumber(ARGV[4])
+	local ttl = tonumber(ARGV[5])
+
+	local last = tonumber(redis.call('get', last_key)) or now
+	local tokens = tonumber(redis.call('get', tokens_key)) or max_tokens
+
+	-- Calculate new tokens based on time elapsed (tokens per nanosecond)
+	local elapsed = now - last
+	local tokens_per_ns = rate / 60 / 1000000000
+	tokens = math.min(max_tokens, tokens + (elapsed * tokens_per_ns))
+
+	local allowed = 0
+	if tokens >= cost then
+		tokens = tokens - cost
+		allowed = 1
+	end
+
+	redis.call('set', tokens_key, tokens, 'EX', ttl)
+	redis.call('set', last_key, now, 'EX', ttl)
+
+	return {allowed, math.floor(tokens)}
+`)
+
 // checkCostBasedLimit checks if the operation is allowed given its cost
 func checkCostBasedLimit(ctx context.Context, client *redis.Client, key string, tokensPerMinute int, cost int) (bool, *RateLimitInfo, error) {
 	now := time.Now()
 	tokensKey := key + ":tokens"
 	lastKey := key + ":last"
 
-	// Lua script for atomic cost-based token bucket operation
-	script := redis.NewScript(`
-		local tokens_key = KEYS[1]
-		local last_key = KEYS[2]
-		local rate = tonumber(ARGV[1])
-		local max_tokens = tonumber(ARGV[2])
-		local now = tonumber(ARGV[3])
-		local cost = tonumber(ARGV[4])
-		local ttl = tonumber(ARGV[5])
-
-		local last = tonumber(redis.call('get', last_key)) or now
-		local tokens = tonumber(redis.call('get', tokens_key)) or max_tokens
-
-		-- Calculate new tokens based on time elapsed (tokens per nanosecond)
-		local elapsed = now - last
-		local tokens_per_ns = rate / 60 / 1000000000
-		tokens = math.min(max_tokens, tokens + (elapsed * tokens_per_ns))
-
-		local allowed = 0
-		if tokens >= cost then
-			tokens = tokens - cost
-			allowed = 1
-		end
-
-		redis.call('set', tokens_key, tokens, 'EX', ttl)
-		redis.call('set', last_key, now, 'EX', ttl)
-
-		return {allowed, math.floor(tokens)}
-	`)
-
-	result, err := script.Run(ctx, client, []string{tokensKey, lastKey},
+	result, err := costBasedLimitScript.Run(ctx, client, []string{tokensKey, lastKey},
 		tokensPerMinute, tokensPerMinute, now.UnixNano(), cost, 120).Slice()
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to run cost-based limit script: %w", err)
@@ -1220,17 +1221,7 @@ func ResetUserRateLimit(ctx context.Context, client *redis.Client, userID string
 	}
 
 	// Find and delete all keys for this user
-	pattern := "ratelimit:*:user:" + userID + "*"
-	keys, err := client.Keys(ctx, pattern).Result()
-	if err != nil {
-		return fmt.Errorf("failed to find rate limit keys: %w", err)
-	}
-
-	if len(keys) == 0 {
-		return nil
-	}
-
-	return client.Del(ctx, keys...).Err()
+	return deleteRateLimitKeys(ctx, client, "ratelimit:*:user:"+userID+"*")
 }
 
 // ResetIPRateLimit resets all rate limit keys for an IP
@@ -1239,17 +1230,30 @@ func ResetIPRateLimit(ctx context.Context, client *redis.Client, ip string) erro
 		return fmt.Errorf("redis client is nil")
 	}
 
-	pattern := "ratelimit:*:ip:" + ip + "*"
-	keys, err := client.Keys(ctx, pattern).Result()
-	if err != nil {
-		return fmt.Errorf("failed to find rate limit keys: %w", err)
-	}
+	return deleteRateLimitKeys(ctx, client, "ratelimit:*:ip:"+ip+"*")
+}
 
-	if len(keys) == 0 {
-		return nil
-	}
+// deleteRateLimitKeys deletes the keys matching pattern. It uses SCAN rather than KEYS
+// so the rate limit Redis is not blocked while the keyspace is walked.
+func deleteRateLimitKeys(ctx context.Context, client *redis.Client, pattern string) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := client.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return fmt.Errorf("failed to find rate limit keys: %w", err)
+		}
 
-	return client.Del(ctx, keys...).Err()
+		if len(keys) > 0 {
+			if err := client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return nil
+		}
+	}
 }
 
 // GetRateLimitStatus returns the current rate limit status for a key
@@ -1261,22 +1265,26 @@ func GetRateLimitStatus(ctx context.Context, client *redis.Client, key string, l
 	now := time.Now()
 	windowStart := now.Add(-window)
 
-	// Count current requests in window
-	count, err := client.ZCount(ctx, key,
+	// Count current requests in window and get TTL for reset time in one round trip
+	pipe := client.Pipeline()
+	countCmd := pipe.ZCount(ctx, key,
 		strconv.FormatInt(windowStart.UnixNano(), 10),
-		strconv.FormatInt(now.UnixNano(), 10)).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get rate limit count: %w", err)
+		strconv.FormatInt(now.UnixNano(), 10))
+	ttlCmd := pipe.TTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get rate limit status: %w", err)
 	}
 
+	count := countCmd.Val()
+
 	remaining := limit - int(count)
 	if remaining < 0 {
 		remaining = 0
 	}
 
-	// Get TTL for reset time
-	ttl, err := client.TTL(ctx, key).Result()
-	if err != nil || ttl < 0 {
+	// Negative when the key does not exist or has no expiry
+	ttl := ttlCmd.Val()
+	if ttl < 0 {
 		ttl = window
 	}
 
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func serve(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestRateLimit_SlidingWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server, client := newTestRedis(t)
+
+	cfg := DefaultRateLimitConfig()
+	cfg.RedisClient = client
+	cfg.IPRequestsPerMinute = 2
+
+	router := gin.New()
+	router.Use(RateLimit(cfg))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	first := serve(router, "/ping")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, serve(router, "/ping").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "/ping").Code)
+
+	// The pipeline also sets the expiry of the window
+	key := "ratelimit:ip:192.0.2.1"
+	assert.Equal(t, time.Minute, server.TTL(key))
+
+	t.Run("fails open without Redis", func(t *testing.T) {
+		server.Close()
+		assert.Equal(t, http.StatusOK, serve(router, "/ping").Code)
+	})
+}
+
+func TestGetRateLimitStatus(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	key := "ratelimit:user:42"
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := checkSlidingWindowRateLimit(ctx, client, key, 5, time.Minute)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	status, err := GetRateLimitStatus(ctx, client, key, 5, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 5, status.Limit)
+	assert.Equal(t, 2, status.Remaining)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), status.Reset, 2*time.Second)
+
+	t.Run("unknown key", func(t *testing.T) {
+		status, err := GetRateLimitStatus(ctx, client, "ratelimit:user:unknown", 5, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 5, status.Remaining)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), status.Reset, 2*time.Second)
+	})
+
+	t.Run("Redis unavailable", func(t *testing.T) {
+		server.Close()
+		status, err := GetRateLimitStatus(ctx, client, key, 5, time.Minute)
+		assert.Error(t, err)
+		assert.Nil(t, status)
+	})
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server, client := newTestRedis(t)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.Use(ConcurrencyLimit(client, 2))
+	router.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serve(router, "/slow").Code
+		}(i)
+	}
+	<-entered
+	<-entered
+
+	key := "concurrent:ip:192.0.2.1"
+	assert.Equal(t, "2", mustGet(t, server, key))
+	assert.Equal(t, 5*time.Minute, server.TTL(key))
+
+	// A third request while two are in flight is rejected without being counted
+	assert.Equal(t, http.StatusServiceUnavailable, serve(router, "/slow").Code)
+	assert.Equal(t, "2", mustGet(t, server, key))
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+	assert.Equal(t, "0", mustGet(t, server, key))
+}
+
+func TestBurstRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, client := newTestRedis(t)
+
+	router := gin.New()
+	router.Use(BurstRateLimit(client, 1, 3))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, remaining := range []string{"2", "1", "0"} {
+		w := serve(router, "/ping")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, remaining, w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w := serve(router, "/ping")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestCheckTokenBucket_Refill(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	key := "ratelimit:burst:user:42"
+
+	// 50 tokens per second, bursts of 2
+	for i := 0; i < 2; i++ {
+		allowed, _, err := checkTokenBucket(ctx, client, key, 50, 2)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+	allowed, info, err := checkTokenBucket(ctx, client, key, 50, 2)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	time.Sleep(60 * time.Millisecond)
+	allowed, _, err = checkTokenBucket(ctx, client, key, 50, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed, "a token is back after 20ms")
+
+	// The script keeps both keys for a minute
+	assert.Equal(t, time.Minute, server.TTL(key+":tokens"))
+	assert.Equal(t, time.Minute, server.TTL(key+":last"))
+
+	// Buckets of other keys are full
+	allowed, info, err = checkTokenBucket(ctx, client, "ratelimit:burst:user:43", 50, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, info.Remaining)
+}
+
+func mustGet(t *testing.T, server *miniredis.Miniredis, key string) string {
+	t.Helper()
+	value, err := server.Get(key)
+	require.NoError(t, err)
+	return value
+}
@@ -20,6 +20,12 @@ type AccessChecker interface {
 	IsOrganizerForFestival(ctx context.Context, userID, festivalID string) (bool, error)
 }
 
+// StandOwnershipChecker checks which stands a vendor owns
+type StandOwnershipChecker interface {
+	// IsStandOwner checks if a user owns a stand
+	IsStandOwner(ctx context.Context, userID, standID string) (bool, error)
+}
+
 // RoleConfig holds configuration for role-based middleware
 type RoleConfig struct {
 	AccessChecker AccessChecker
@@ -374,6 +380,55 @@ func RequireStandAccess(checker AccessChecker) gin.HandlerFunc {
 	}
 }
 
+// RequireStandOwnership middleware checks if the user (vendor) owns the stand
+// The stand ID is expected to be in the URL parameter :standId
+func RequireStandOwnership(checker StandOwnershipChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			respondForbidden(c, "User not authenticated")
+			return
+		}
+
+		// Admins have access to all stands
+		if hasRoleInContext(c, RoleAdmin) {
+			c.Next()
+			return
+		}
+
+		if !hasRoleInContext(c, RoleVendor) {
+			respondForbidden(c, "Insufficient permissions. Required role: "+RoleVendor)
+			return
+		}
+
+		standID := c.Param("standId")
+		if standID == "" {
+			respondForbidden(c, "Stand ID not found in request")
+			return
+		}
+
+		// Validate stand ID format
+		if _, err := uuid.Parse(standID); err != nil {
+			respondForbidden(c, "Invalid stand ID format")
+			return
+		}
+
+		// Ownership is only ever read from the database so that revoking it takes
+		// effect before the token expires
+		owns, err := checker.IsStandOwner(c.Request.Context(), userID, standID)
+		if err != nil {
+			respondInternalError(c, "Failed to check stand ownership")
+			return
+		}
+		if !owns {
+			respondForbidden(c, "You do not own this stand")
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // RequireOwnerOrAdmin middleware checks if the user is the owner of the resource or an admin
 // The owner ID is expected to be in the URL parameter specified by ownerParam
 func RequireOwnerOrAdmin(ownerParam string) gin.HandlerFunc {
@@ -487,6 +542,7 @@ func respondInternalError(c *gin.Context, message string) {
 var RoleHierarchy = map[string]int{
 	RoleUser:      0,
 	RoleStaff:     1,
+	RoleVendor:    1,
 	RoleOrganizer: 2,
 	RoleAdmin:     3,
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 )
@@ -218,7 +219,7 @@ func enforceHTTPS(c *gin.Context) bool {
 	log.Warn().
 		Str("from", "http://"+host+path).
 		Str("to", httpsURL).
-		Str("ip", c.ClientIP()).
+		Str("ip", privacy.LogIP(c.ClientIP())).
 		Msg("Redirecting HTTP to HTTPS")
 
 	c.Redirect(http.StatusMovedPermanently, httpsURL)
@@ -363,7 +364,7 @@ func validateCSRF(c *gin.Context, cfg SecurityConfig) bool {
 		log.Warn().
 			Str("path", c.Request.URL.Path).
 			Str("method", c.Request.Method).
-			Str("ip", c.ClientIP()).
+			Str("ip", privacy.LogIP(c.ClientIP())).
 			Msg("CSRF token mismatch")
 		respondSecurityError(c, "CSRF_INVALID", "Invalid CSRF token")
 		return false
@@ -373,7 +374,7 @@ func validateCSRF(c *gin.Context, cfg SecurityConfig) bool {
 	if !verifyCSRFToken(c, cfg, cookieToken) {
 		log.Warn().
 			Str("path", c.Request.URL.Path).
-			Str("ip", c.ClientIP()).
+			Str("ip", privacy.LogIP(c.ClientIP())).
 			Msg("CSRF token not found in store")
 		respondSecurityError(c, "CSRF_INVALID", "Invalid CSRF token")
 		return false
@@ -545,7 +546,7 @@ func sanitizeAndValidateInput(c *gin.Context, cfg SecurityConfig) bool {
 					Str("type", threat).
 					Str("param", key).
 					Str("path", c.Request.URL.Path).
-					Str("ip", c.ClientIP()).
+					Str("ip", privacy.LogIP(c.ClientIP())).
 					Msg("Potential attack detected in query parameter")
 				respondSecurityError(c, "MALICIOUS_INPUT", "Potentially malicious input detected")
 				return false
@@ -563,7 +564,7 @@ func sanitizeAndValidateInput(c *gin.Context, cfg SecurityConfig) bool {
 					Str("type", threat).
 					Str("header", header).
 					Str("path", c.Request.URL.Path).
-					Str("ip", c.ClientIP()).
+					Str("ip", privacy.LogIP(c.ClientIP())).
 					Msg("Potential attack detected in header")
 				respondSecurityError(c, "MALICIOUS_INPUT", "Potentially malicious input detected")
 				return false
@@ -584,7 +585,7 @@ func sanitizeAndValidateInput(c *gin.Context, cfg SecurityConfig) bool {
 								Str("type", threat).
 								Str("field", key).
 								Str("path", c.Request.URL.Path).
-								Str("ip", c.ClientIP()).
+								Str("ip", privacy.LogIP(c.ClientIP())).
 								Msg("Potential attack detected in form data")
 							respondSecurityError(c, "MALICIOUS_INPUT", "Potentially malicious input detected")
 							return false
@@ -0,0 +1,82 @@
+// Package clock tells the time of festivals. Festivals run on the wall clock, except
+// sandbox festivals attached to a test clock, whose virtual time only moves when it is
+// advanced, so that scheduled jobs can be fast-forwarded in tests and demos.
+package clock
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the current time of festivals
+type Clock interface {
+	// Now returns the current time of a festival
+	Now(ctx context.Context, festivalID uuid.UUID) time.Time
+	// Virtual returns the festivals running on a test clock, with their current time
+	Virtual(ctx context.Context) (map[uuid.UUID]time.Time, error)
+}
+
+// Wall is the clock of every festival when test clocks are not enabled
+type Wall struct{}
+
+func (Wall) Now(ctx context.Context, festivalID uuid.UUID) time.Time {
+	return time.Now()
+}
+
+func (Wall) Virtual(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+	return nil, nil
+}
+
+// Fixed is a clock where the festivals listed are at their time and the others at
+// the wall time, for tests
+type Fixed map[uuid.UUID]time.Time
+
+func (f Fixed) Now(ctx context.Context, festivalID uuid.UUID) time.Time {
+	if t, ok := f[festivalID]; ok {
+		return t
+	}
+	return time.Now()
+}
+
+func (f Fixed) Virtual(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+	return f, nil
+}
+
+// festivals returns the IDs of the festivals of a Virtual result
+func festivals(virtual map[uuid.UUID]time.Time) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(virtual))
+	for id := range virtual {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Scope restricts a job run over every festival to some of them
+type Scope struct {
+	FestivalID *uuid.UUID  // Only this festival
+	Exclude    []uuid.UUID // Not these festivals
+}
+
+// Run is a pass of a scheduled job over the festivals of a scope, at their time
+type Run struct {
+	Now   time.Time
+	Scope Scope
+}
+
+// Runs splits a scheduled job over every festival into a run at now for the festivals
+// on the wall clock, and a run per festival on a test clock at its virtual time
+func Runs(ctx context.Context, c Clock, now time.Time) ([]Run, error) {
+	virtual, err := c.Virtual(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []Run{{Now: now, Scope: Scope{Exclude: festivals(virtual)}}}
+	for id, t := range virtual {
+		festivalID := id
+		runs = append(runs, Run{Now: t, Scope: Scope{FestivalID: &festivalID}})
+	}
+	return runs, nil
+}
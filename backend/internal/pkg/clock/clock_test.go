@@ -0,0 +1,42 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuns(t *testing.T) {
+	now := time.Date(2026, 7, 18, 12, 0, 0, 0, time.UTC)
+	sandbox := uuid.New()
+	virtual := time.Date(2026, 7, 20, 23, 30, 0, 0, time.UTC)
+
+	runs, err := Runs(context.Background(), Fixed{sandbox: virtual}, now)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	assert.Equal(t, now, runs[0].Now)
+	assert.Nil(t, runs[0].Scope.FestivalID)
+	assert.Equal(t, []uuid.UUID{sandbox}, runs[0].Scope.Exclude, "wall run leaves the sandbox out")
+
+	assert.Equal(t, virtual, runs[1].Now)
+	require.NotNil(t, runs[1].Scope.FestivalID)
+	assert.Equal(t, sandbox, *runs[1].Scope.FestivalID)
+
+	runs, err = Runs(context.Background(), Wall{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []Run{{Now: now, Scope: Scope{Exclude: []uuid.UUID{}}}}, runs)
+}
+
+func TestFixed(t *testing.T) {
+	sandbox := uuid.New()
+	virtual := time.Date(2026, 7, 20, 23, 30, 0, 0, time.UTC)
+	c := Fixed{sandbox: virtual}
+
+	assert.Equal(t, virtual, c.Now(context.Background(), sandbox))
+	assert.WithinDuration(t, time.Now(), c.Now(context.Background(), uuid.New()), time.Second)
+}
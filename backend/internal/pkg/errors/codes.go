@@ -55,6 +55,7 @@ const (
 	ErrCodeFestivalNotStarted   = "FESTIVAL_NOT_STARTED"
 	ErrCodeStandNotFound        = "STAND_NOT_FOUND"
 	ErrCodeStandClosed          = "STAND_CLOSED"
+	ErrCodeDayClosed            = "DAY_CLOSED"
 	ErrCodeProductNotFound      = "PRODUCT_NOT_FOUND"
 	ErrCodeProductUnavailable   = "PRODUCT_UNAVAILABLE"
 	ErrCodeOutOfStock           = "OUT_OF_STOCK"
@@ -164,6 +165,7 @@ var CodeToHTTPStatus = map[string]int{
 	ErrCodeFestivalNotStarted:   422,
 	ErrCodeStandNotFound:        404,
 	ErrCodeStandClosed:          422,
+	ErrCodeDayClosed:            409,
 	ErrCodeProductNotFound:      404,
 	ErrCodeProductUnavailable:   422,
 	ErrCodeOutOfStock:           422,
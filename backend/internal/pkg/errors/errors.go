@@ -35,6 +35,7 @@ var (
 	ErrTransactionNotAllowed = errors.New("transaction not allowed")
 	ErrStandNotFound         = errors.New("stand not found")
 	ErrStandClosed           = errors.New("stand is closed")
+	ErrDayClosed             = errors.New("business day is closed")
 	ErrProductNotFound       = errors.New("product not found")
 	ErrOutOfStock            = errors.New("product out of stock")
 	ErrOrderNotFound         = errors.New("order not found")
@@ -212,6 +213,14 @@ func SentinelToAppError(err error) *AppError {
 			Kind:       KindBusiness,
 			StatusCode: http.StatusUnprocessableEntity,
 		}
+	case errors.Is(err, ErrDayClosed):
+		return &AppError{
+			Code:       ErrCodeDayClosed,
+			Message:    "The business day is closed, changes need an adjustment on the day close",
+			Err:        err,
+			Kind:       KindConflict,
+			StatusCode: http.StatusConflict,
+		}
 	case errors.Is(err, ErrProductNotFound):
 		return &AppError{
 			Code:       ErrCodeProductNotFound,
@@ -0,0 +1,392 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// MaxMind DB format, see https://maxmind.github.io/MaxMind-DB/
+
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Data section types
+const (
+	typeExtended = 0
+	typePointer  = 1
+	typeString   = 2
+	typeDouble   = 3
+	typeBytes    = 4
+	typeUint16   = 5
+	typeUint32   = 6
+	typeMap      = 7
+	typeInt32    = 8
+	typeUint64   = 9
+	typeUint128  = 10
+	typeArray    = 11
+	typeBool     = 14
+	typeFloat    = 15
+)
+
+// maxDepth bounds the nesting of decoded values, so a corrupt database cannot
+// recurse forever
+const maxDepth = 32
+
+var errInvalidDatabase = errors.New("invalid MaxMind database")
+
+// Metadata describes a MaxMind database
+type Metadata struct {
+	DatabaseType string
+	IPVersion    int
+	NodeCount    uint
+	RecordSize   uint
+	BuildEpoch   uint64
+}
+
+// Reader looks up IP addresses in a MaxMind database (.mmdb) held in memory
+type Reader struct {
+	buf          []byte
+	data         []byte // Data section
+	metadata     Metadata
+	ipv4Start    uint // Node of ::/96, where IPv4 lookups start in IPv6 trees
+	nodeByteSize uint
+}
+
+// Open reads a MaxMind database file
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MaxMind database: %w", err)
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes parses a MaxMind database
+func FromBytes(buf []byte) (*Reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start == -1 {
+		return nil, fmt.Errorf("%w: metadata not found", errInvalidDatabase)
+	}
+
+	meta := &decoder{buf: buf[start+len(metadataMarker):]}
+	raw, _, err := meta.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode MaxMind metadata: %w", err)
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", errInvalidDatabase)
+	}
+
+	metadata := Metadata{
+		DatabaseType: stringField(fields, "database_type"),
+		IPVersion:    int(uintField(fields, "ip_version")),
+		NodeCount:    uint(uintField(fields, "node_count")),
+		RecordSize:   uint(uintField(fields, "record_size")),
+		BuildEpoch:   uintField(fields, "build_epoch"),
+	}
+	switch metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: unsupported record size %d", errInvalidDatabase, metadata.RecordSize)
+	}
+	if metadata.IPVersion != 4 && metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported IP version %d", errInvalidDatabase, metadata.IPVersion)
+	}
+
+	nodeByteSize := metadata.RecordSize / 4
+	treeSize := metadata.NodeCount * nodeByteSize
+	if treeSize+16 > uint(start) {
+		return nil, fmt.Errorf("%w: search tree larger than the file", errInvalidDatabase)
+	}
+
+	r := &Reader{
+		buf:          buf,
+		data:         buf[treeSize+16 : start],
+		metadata:     metadata,
+		nodeByteSize: nodeByteSize,
+	}
+
+	if metadata.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < metadata.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+
+	return r, nil
+}
+
+// Metadata returns the metadata of the database
+func (r *Reader) Metadata() Metadata {
+	return r.metadata
+}
+
+// Lookup returns the record of the network containing ip, nil when the database has
+// none
+func (r *Reader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	node, bits, err := r.start(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeCount := r.metadata.NodeCount
+	for i := 0; i < len(bits)*8 && node < nodeCount; i++ {
+		bit := uint(bits[i/8]>>(7-uint(i%8))) & 1
+		node = r.record(node, bit)
+	}
+
+	if node == nodeCount {
+		return nil, nil
+	}
+	if node < nodeCount {
+		return nil, fmt.Errorf("%w: search tree deeper than the address", errInvalidDatabase)
+	}
+
+	offset := node - nodeCount - 16
+	if offset >= uint(len(r.data)) {
+		return nil, fmt.Errorf("%w: record points outside the data section", errInvalidDatabase)
+	}
+
+	d := &decoder{buf: r.data}
+	value, _, err := d.decode(offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: record is not a map", errInvalidDatabase)
+	}
+	return record, nil
+}
+
+// start returns the node to start the lookup of ip from and the bits to walk
+func (r *Reader) start(ip net.IP) (uint, []byte, error) {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		if r.metadata.IPVersion == 6 {
+			return r.ipv4Start, ipv4, nil
+		}
+		return 0, ipv4, nil
+	}
+
+	ipv6 := ip.To16()
+	if ipv6 == nil {
+		return 0, nil, fmt.Errorf("invalid IP address %q", ip)
+	}
+	if r.metadata.IPVersion == 4 {
+		return 0, nil, fmt.Errorf("IPv6 address %s in an IPv4-only database", ip)
+	}
+	return 0, ipv6, nil
+}
+
+// record reads the left (bit 0) or right (bit 1) record of a node
+func (r *Reader) record(node, bit uint) uint {
+	b := r.buf[node*r.nodeByteSize:]
+	switch r.metadata.RecordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// decoder decodes the values of the data section
+type decoder struct {
+	buf []byte
+}
+
+// decode decodes the value at offset and returns it with the offset following it
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, fmt.Errorf("%w: values nested too deeply", errInvalidDatabase)
+	}
+
+	typeNum, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typeNum == typePointer {
+		pointer, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+
+	switch typeNum {
+	case typeMap:
+		value := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, item interface{}
+			key, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map key is not a string", errInvalidDatabase)
+			}
+			item, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value[name] = item
+		}
+		return value, offset, nil
+
+	case typeArray:
+		value := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var item interface{}
+			item, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value = append(value, item)
+		}
+		return value, offset, nil
+
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buf)) {
+		return nil, 0, fmt.Errorf("%w: value past the end of the data section", errInvalidDatabase)
+	}
+	b := d.buf[offset:end]
+
+	switch typeNum {
+	case typeString:
+		return string(b), end, nil
+	case typeBytes:
+		return append([]byte(nil), b...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: double of %d bytes", errInvalidDatabase, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: float of %d bytes", errInvalidDatabase, size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), end, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("%w: integer of %d bytes", errInvalidDatabase, size)
+		}
+		var value uint64
+		for _, c := range b {
+			value = value<<8 | uint64(c)
+		}
+		return value, end, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("%w: int32 of %d bytes", errInvalidDatabase, size)
+		}
+		var value uint32
+		for _, c := range b {
+			value = value<<8 | uint32(c)
+		}
+		return int64(int32(value)), end, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(b), end, nil
+	}
+
+	return nil, 0, fmt.Errorf("%w: unknown data type %d", errInvalidDatabase, typeNum)
+}
+
+// control reads the control byte at offset and returns the type and size of the value
+// with the offset of its payload. Pointers return the size bits unchanged.
+func (d *decoder) control(offset uint) (uint, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("%w: value past the end of the data section", errInvalidDatabase)
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typeNum := uint(ctrl >> 5)
+	if typeNum == typePointer {
+		return typeNum, uint(ctrl & 0x1f), offset, nil
+	}
+	if typeNum == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, fmt.Errorf("%w: truncated extended type", errInvalidDatabase)
+		}
+		typeNum = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		extra := size - 28
+		if offset+extra > uint(len(d.buf)) {
+			return 0, 0, 0, fmt.Errorf("%w: truncated size", errInvalidDatabase)
+		}
+		var n uint
+		for _, c := range d.buf[offset : offset+extra] {
+			n = n<<8 | uint(c)
+		}
+		offset += extra
+		switch size {
+		case 29:
+			size = 29 + n
+		case 30:
+			size = 285 + n
+		default:
+			size = 65821 + n
+		}
+	}
+
+	return typeNum, size, offset, nil
+}
+
+// pointer resolves a pointer from the size bits of its control byte and returns its
+// target with the offset following it
+func (d *decoder) pointer(bits, offset uint) (uint, uint, error) {
+	length := (bits>>3)&0x3 + 1
+	if offset+length > uint(len(d.buf)) {
+		return 0, 0, fmt.Errorf("%w: truncated pointer", errInvalidDatabase)
+	}
+
+	var n uint
+	for _, c := range d.buf[offset : offset+length] {
+		n = n<<8 | uint(c)
+	}
+	next := offset + length
+
+	switch length {
+	case 1:
+		return (bits&0x7)<<8 | n, next, nil
+	case 2:
+		return ((bits&0x7)<<16 | n) + 2048, next, nil
+	case 3:
+		return ((bits&0x7)<<24 | n) + 526336, next, nil
+	default:
+		return n, next, nil
+	}
+}
+
+func stringField(fields map[string]interface{}, name string) string {
+	value, _ := fields[name].(string)
+	return value
+}
+
+func uintField(fields map[string]interface{}, name string) uint64 {
+	value, _ := fields[name].(uint64)
+	return value
+}
@@ -0,0 +1,184 @@
+package geoip
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB writes small MaxMind databases with the subset of the format the tests need
+type testDB struct {
+	nodes [][2]int // -1 for no data, -2-i for the record i, otherwise a node
+	data  bytes.Buffer
+	// offsets of the records in the data section
+	offsets []int
+}
+
+func newTestDB() *testDB {
+	return &testDB{nodes: [][2]int{{-1, -1}}}
+}
+
+// insert maps a network to a record already written to the data section
+func (db *testDB) insert(network string, record int) {
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		panic(err)
+	}
+	ip := ipNet.IP.To16()
+	ones, bits := ipNet.Mask.Size()
+	if bits == 32 {
+		// IPv4 networks live under ::/96, not under the IPv4-mapped ::ffff:0:0/96
+		ip = append(make(net.IP, 12), ipNet.IP.To4()...)
+		ones += 96
+	}
+
+	node := 0
+	for i := 0; i < ones; i++ {
+		bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+		if i == ones-1 {
+			db.nodes[node][bit] = -2 - record
+			return
+		}
+		next := db.nodes[node][bit]
+		if next < 0 {
+			db.nodes = append(db.nodes, [2]int{-1, -1})
+			next = len(db.nodes) - 1
+			db.nodes[node][bit] = next
+		}
+		node = next
+	}
+}
+
+// record writes a record to the data section and returns its index
+func (db *testDB) record(value []byte) int {
+	db.offsets = append(db.offsets, db.data.Len())
+	db.data.Write(value)
+	return len(db.offsets) - 1
+}
+
+// bytes returns the database with 28-bit records
+func (db *testDB) bytes(t *testing.T) []byte {
+	nodeCount := len(db.nodes)
+	value := func(v int) uint32 {
+		switch {
+		case v == -1:
+			return uint32(nodeCount)
+		case v < -1:
+			return uint32(nodeCount + 16 + db.offsets[-2-v])
+		default:
+			return uint32(v)
+		}
+	}
+
+	var out bytes.Buffer
+	for _, node := range db.nodes {
+		left, right := value(node[0]), value(node[1])
+		out.Write([]byte{
+			byte(left >> 16), byte(left >> 8), byte(left),
+			byte((left>>24)<<4 | (right>>24)&0x0f),
+			byte(right >> 16), byte(right >> 8), byte(right),
+		})
+	}
+	out.Write(make([]byte, 16))
+	out.Write(db.data.Bytes())
+	out.Write(metadataMarker)
+	out.Write(encodeMap(
+		"node_count", encodeUint(6, uint64(nodeCount)),
+		"record_size", encodeUint(5, 28),
+		"ip_version", encodeUint(5, 6),
+		"database_type", encodeString("Test-Country"),
+		"build_epoch", encodeUint(9, 1752850800),
+	))
+	return out.Bytes()
+}
+
+// control encodes a control byte, for sizes below 285
+func control(typeNum, size int) []byte {
+	var extra []byte
+	if size >= 29 {
+		extra = []byte{byte(size - 29)}
+		size = 29
+	}
+	out := []byte{byte(typeNum<<5 | size)}
+	if typeNum > 7 {
+		out = []byte{byte(size), byte(typeNum - 7)}
+	}
+	return append(out, extra...)
+}
+
+func encodeString(s string) []byte {
+	return append(control(typeString, len(s)), s...)
+}
+
+func encodeUint(typeNum int, v uint64) []byte {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	return append(control(typeNum, len(b)), b...)
+}
+
+func encodeBool(v bool) []byte {
+	if v {
+		return control(typeBool, 1)
+	}
+	return control(typeBool, 0)
+}
+
+func encodePointer(offset int) []byte {
+	return []byte{byte(typePointer<<5 | (offset>>8)&0x7), byte(offset)}
+}
+
+func encodeMap(pairs ...interface{}) []byte {
+	out := control(typeMap, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, encodeString(pairs[i].(string))...)
+		out = append(out, pairs[i+1].([]byte)...)
+	}
+	return out
+}
+
+func TestReader_Lookup(t *testing.T) {
+	db := newTestDB()
+	europe := db.record(encodeMap("code", encodeString("EU")))
+	france := db.record(encodeMap(
+		"country", encodeMap("iso_code", encodeString("FR"), "is_in_european_union", encodeBool(true)),
+		// Shared values are stored once and pointed to
+		"continent", encodePointer(db.offsets[europe]),
+		"autonomous_system_number", encodeUint(6, 16276),
+		"autonomous_system_organization", encodeString("OVH SAS"),
+	))
+	us := db.record(encodeMap(
+		"registered_country", encodeMap("iso_code", encodeString("US")),
+		"continent", encodeMap("code", encodeString("NA")),
+	))
+	db.insert("192.0.2.0/24", france)
+	db.insert("2001:db8::/32", us)
+
+	reader, err := FromBytes(db.bytes(t))
+	require.NoError(t, err)
+	assert.Equal(t, "Test-Country", reader.Metadata().DatabaseType)
+	assert.Equal(t, uint(28), reader.Metadata().RecordSize)
+
+	resolver := NewResolverFromReaders(reader, reader)
+
+	location, err := resolver.Resolve(net.ParseIP("192.0.2.44"))
+	require.NoError(t, err)
+	assert.Equal(t, Location{CountryCode: "FR", ContinentCode: "EU", InEU: true, ASN: 16276, ASOrganization: "OVH SAS"}, location)
+
+	location, err = resolver.Resolve(net.ParseIP("2001:db8:1::7"))
+	require.NoError(t, err)
+	assert.Equal(t, "US", location.CountryCode)
+	assert.Equal(t, "NA", location.ContinentCode)
+	assert.False(t, location.InEU)
+
+	location, err = resolver.Resolve(net.ParseIP("198.51.100.1"))
+	require.NoError(t, err)
+	assert.Equal(t, Location{}, location)
+
+	_, err = FromBytes([]byte("not a database"))
+	assert.Error(t, err)
+}
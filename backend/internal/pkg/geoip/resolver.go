@@ -0,0 +1,84 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+)
+
+// Location is what the databases know of a client IP. Fields are empty when the IP is
+// not in a database, e.g. private addresses.
+type Location struct {
+	CountryCode    string `json:"countryCode,omitempty"` // ISO 3166-1 alpha-2
+	ContinentCode  string `json:"continentCode,omitempty"`
+	InEU           bool   `json:"inEu"`
+	ASN            uint   `json:"asn,omitempty"`
+	ASOrganization string `json:"asOrganization,omitempty"`
+}
+
+// Resolver resolves client IPs with a country (or city) database and an ASN database,
+// such as GeoLite2-Country and GeoLite2-ASN. Either database may be missing.
+type Resolver struct {
+	country *Reader
+	asn     *Reader
+}
+
+// NewResolver opens the databases of the given paths, skipping empty ones
+func NewResolver(countryPath, asnPath string) (*Resolver, error) {
+	r := &Resolver{}
+	if countryPath != "" {
+		reader, err := Open(countryPath)
+		if err != nil {
+			return nil, fmt.Errorf("country database: %w", err)
+		}
+		r.country = reader
+	}
+	if asnPath != "" {
+		reader, err := Open(asnPath)
+		if err != nil {
+			return nil, fmt.Errorf("ASN database: %w", err)
+		}
+		r.asn = reader
+	}
+	return r, nil
+}
+
+// NewResolverFromReaders creates a resolver from opened databases, either may be nil
+func NewResolverFromReaders(country, asn *Reader) *Resolver {
+	return &Resolver{country: country, asn: asn}
+}
+
+// Resolve returns the location of an IP
+func (r *Resolver) Resolve(ip net.IP) (Location, error) {
+	var location Location
+
+	if r.country != nil {
+		record, err := r.country.Lookup(ip)
+		if err != nil {
+			return location, err
+		}
+		// Anycast and satellite networks only have the country they are registered in
+		country := mapField(record, "country")
+		if country == nil {
+			country = mapField(record, "registered_country")
+		}
+		location.CountryCode = stringField(country, "iso_code")
+		location.InEU, _ = country["is_in_european_union"].(bool)
+		location.ContinentCode = stringField(mapField(record, "continent"), "code")
+	}
+
+	if r.asn != nil {
+		record, err := r.asn.Lookup(ip)
+		if err != nil {
+			return location, err
+		}
+		location.ASN = uint(uintField(record, "autonomous_system_number"))
+		location.ASOrganization = stringField(record, "autonomous_system_organization")
+	}
+
+	return location, nil
+}
+
+func mapField(fields map[string]interface{}, name string) map[string]interface{} {
+	value, _ := fields[name].(map[string]interface{})
+	return value
+}
@@ -0,0 +1,40 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatDate formats a date with localized weekday and month names,
+// e.g. "Saturday, July 12, 2025" or "samedi 12 juillet 2025"
+func FormatDate(locale string, t time.Time) string {
+	return T(locale, "format.date", Params{
+		"weekday": T(locale, "date.weekday."+strconv.Itoa(int(t.Weekday()))),
+		"day":     t.Day(),
+		"month":   T(locale, "date.month."+strconv.Itoa(int(t.Month()))),
+		"year":    t.Year(),
+	})
+}
+
+// FormatDateTime formats a date and time of day, e.g. "Saturday, July 12, 2025 at 3:04 PM"
+func FormatDateTime(locale string, t time.Time) string {
+	return T(locale, "format.datetime", Params{
+		"date": FormatDate(locale, t),
+		"time": t.Format(T(locale, "format.time")),
+	})
+}
+
+// FormatAmount formats an amount in cents with the locale's decimal separator,
+// e.g. "12.50 EUR" or "12,50 EUR"
+func FormatAmount(locale string, cents int64, currency string) string {
+	amount := fmt.Sprintf("%.2f", float64(cents)/100)
+	if separator := T(locale, "format.decimal_separator"); separator != "." {
+		amount = strings.Replace(amount, ".", separator, 1)
+	}
+	if currency == "" {
+		return amount
+	}
+	return amount + " " + currency
+}
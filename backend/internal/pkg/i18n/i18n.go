@@ -0,0 +1,163 @@
+// Package i18n provides message catalogs and locale resolution for API
+// messages and generated documents.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the locale used when no supported locale can be resolved
+const Default = "en"
+
+// Supported lists the locales with a message catalog
+var Supported = []string{"en", "fr", "de", "nl"}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps a locale to its flat key -> message catalog
+var catalogs = loadCatalogs()
+
+// Params holds the values substituted into {name} placeholders
+type Params map[string]interface{}
+
+func loadCatalogs() map[string]map[string]string {
+	loaded := make(map[string]map[string]string, len(Supported))
+	for _, locale := range Supported {
+		data, err := localeFiles.ReadFile(path.Join("locales", locale+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale, err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale, err))
+		}
+		loaded[locale] = messages
+	}
+	return loaded
+}
+
+// IsSupported checks if a locale has a message catalog
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Normalize reduces a language tag like "fr-BE" or "de_CH" to a supported
+// locale, or returns "" when the language is not supported
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if IsSupported(tag) {
+		return tag
+	}
+	return ""
+}
+
+// ParseAcceptLanguage returns the language tags of an Accept-Language header
+// ordered by decreasing quality, skipping wildcards and tags with q=0
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+		if quality <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// Resolve picks the locale of a request: the user's saved preference wins,
+// then the best supported language of the Accept-Language header, then Default
+func Resolve(preferred, acceptLanguage string) string {
+	if locale := Normalize(preferred); locale != "" {
+		return locale
+	}
+	for _, tag := range ParseAcceptLanguage(acceptLanguage) {
+		if locale := Normalize(tag); locale != "" {
+			return locale
+		}
+	}
+	return Default
+}
+
+// Lookup returns the message of a key in a locale without any fallback
+func Lookup(locale, key string) (string, bool) {
+	message, ok := catalogs[locale][key]
+	return message, ok
+}
+
+// T translates a key, falling back to the default locale and then to the key
+// itself, and substitutes {name} placeholders from params
+func T(locale, key string, params ...Params) string {
+	message, ok := Lookup(locale, key)
+	if !ok {
+		message, ok = Lookup(Default, key)
+	}
+	if !ok {
+		message = key
+	}
+
+	for _, p := range params {
+		for name, value := range p {
+			message = strings.ReplaceAll(message, "{"+name+"}", fmt.Sprint(value))
+		}
+	}
+	return message
+}
+
+type contextKey struct{}
+
+// WithLocale returns a context carrying the locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale carried by the context, or Default
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default
+}
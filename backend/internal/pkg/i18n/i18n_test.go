@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCatalogsComplete tests that every locale translates every key of the default catalog
+func TestCatalogsComplete(t *testing.T) {
+	for _, locale := range Supported {
+		for key := range catalogs[Default] {
+			_, ok := Lookup(locale, key)
+			assert.True(t, ok, "%s is missing %s", locale, key)
+		}
+		for key := range catalogs[locale] {
+			_, ok := Lookup(Default, key)
+			assert.True(t, ok, "%s has unknown key %s", locale, key)
+		}
+	}
+}
+
+// TestNormalize tests reduction of language tags to supported locales
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"fr":    "fr",
+		"fr-BE": "fr",
+		"de_CH": "de",
+		" NL ":  "nl",
+		"en-US": "en",
+		"es":    "",
+		"":      "",
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, Normalize(input), input)
+	}
+}
+
+// TestParseAcceptLanguage tests ordering of Accept-Language tags by quality
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"nl-BE", "fr", "en"}, ParseAcceptLanguage("en;q=0.5, nl-BE, fr;q=0.8"))
+	assert.Equal(t, []string{"de"}, ParseAcceptLanguage("de, *;q=0.1, fr;q=0"))
+	assert.Empty(t, ParseAcceptLanguage(""))
+}
+
+// TestResolve tests locale resolution precedence
+func TestResolve(t *testing.T) {
+	assert.Equal(t, "nl", Resolve("nl", "fr-FR,fr;q=0.9"))
+	assert.Equal(t, "fr", Resolve("", "es-ES, fr-FR;q=0.9, en;q=0.8"))
+	assert.Equal(t, "de", Resolve("pt", "de-AT"))
+	assert.Equal(t, Default, Resolve("", "es, it;q=0.5"))
+	assert.Equal(t, Default, Resolve("", ""))
+}
+
+// TestT tests translation, placeholders and fallbacks
+func TestT(t *testing.T) {
+	assert.Equal(t, "Willkommen bei Rock Werchter!", T("de", "email.welcome.subject_festival", Params{"festival": "Rock Werchter"}))
+	assert.Equal(t, "doit être au moins 3", T("fr", "validation.min", Params{"param": 3}))
+
+	// Unknown locale falls back to the default catalog, unknown key to the key itself
+	assert.Equal(t, "Sales Report", T("es", "report.title.SALES"))
+	assert.Equal(t, "report.title.UNKNOWN", T("fr", "report.title.UNKNOWN"))
+}
+
+// TestContext tests carrying the locale in a context
+func TestContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, "nl", FromContext(WithLocale(context.Background(), "nl")))
+}
+
+// TestFormat tests localized date and amount formatting
+func TestFormat(t *testing.T) {
+	date := time.Date(2025, time.July, 12, 15, 4, 0, 0, time.UTC)
+
+	assert.Equal(t, "Saturday, July 12, 2025", FormatDate("en", date))
+	assert.Equal(t, "samedi 12 juillet 2025", FormatDate("fr", date))
+	assert.Equal(t, "Samstag, 12. Juli 2025", FormatDate("de", date))
+	assert.Equal(t, "zaterdag 12 juli 2025 om 15:04", FormatDateTime("nl", date))
+	assert.Equal(t, "Saturday, July 12, 2025 at 3:04 PM", FormatDateTime("en", date))
+
+	assert.Equal(t, "12.50 EUR", FormatAmount("en", 1250, "EUR"))
+	assert.Equal(t, "12,50 EUR", FormatAmount("fr", 1250, "EUR"))
+	assert.Equal(t, "-3,05", FormatAmount("de", -305, ""))
+}
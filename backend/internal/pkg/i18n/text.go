@@ -0,0 +1,187 @@
+package i18n
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Translation errors
+var (
+	ErrInvalidLanguage      = errors.New("translations must be keyed by language tags like fr or fr-BE")
+	ErrMissingDefaultLocale = errors.New("translations must include the default locale of the festival")
+)
+
+// CanonicalTag formats a language tag as a lowercase language with an optional
+// uppercase region, e.g. "fr_be" as "fr-BE", or returns "" when it is not one
+func CanonicalTag(tag string) string {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	language, region, hasRegion := strings.Cut(tag, "-")
+	if len(language) < 2 || len(language) > 3 || !isLetters(language) {
+		return ""
+	}
+	language = strings.ToLower(language)
+	if !hasRegion {
+		return language
+	}
+
+	switch {
+	case len(region) == 2 && isLetters(region):
+		return language + "-" + strings.ToUpper(region)
+	case len(region) == 3 && strings.Trim(region, "0123456789") == "":
+		return language + "-" + region
+	}
+	return ""
+}
+
+// BaseLanguage returns the language of a canonical tag, e.g. "fr" for "fr-BE"
+func BaseLanguage(tag string) string {
+	language, _, _ := strings.Cut(tag, "-")
+	return language
+}
+
+// Negotiate returns the languages a request asks content in, most wanted first: the
+// comma-separated lang query parameter, then the Accept-Language header. Unlike
+// Resolve, it is not limited to the locales with a message catalog.
+func Negotiate(lang, acceptLanguage string) []string {
+	var languages []string
+	seen := make(map[string]bool)
+	for _, tag := range append(strings.Split(lang, ","), ParseAcceptLanguage(acceptLanguage)...) {
+		if tag = CanonicalTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			languages = append(languages, tag)
+		}
+	}
+	return languages
+}
+
+// Text is a text written by organizers or vendors in several languages, keyed by
+// language tag and stored as JSONB
+type Text map[string]string
+
+func (t Text) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *Text) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into i18n.Text", value)
+	}
+	return json.Unmarshal(data, t)
+}
+
+// Normalize returns the translations with canonical language tags and without blank
+// texts. Unless there are none, they must include defaultLocale.
+func (t Text) Normalize(defaultLocale string) (Text, error) {
+	normalized := make(Text, len(t))
+	for tag, text := range t {
+		canonical := CanonicalTag(tag)
+		if canonical == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLanguage, tag)
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			normalized[canonical] = text
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	if _, ok := normalized[CanonicalTag(defaultLocale)]; !ok {
+		return nil, fmt.Errorf("%w (%s)", ErrMissingDefaultLocale, defaultLocale)
+	}
+	return normalized, nil
+}
+
+// UpdateText applies the update of a text in the default locale and of its translations.
+// A new text replaces the translation in defaultLocale; new translations replace all of
+// them, and their text in defaultLocale becomes the text. nil keeps either.
+func UpdateText(defaultLocale, text string, translations Text, newText *string, newTranslations Text) (string, Text, error) {
+	if newText != nil {
+		text = *newText
+		if len(translations) > 0 && strings.TrimSpace(text) != "" {
+			updated := make(Text, len(translations))
+			for tag, translation := range translations {
+				updated[tag] = translation
+			}
+			updated[CanonicalTag(defaultLocale)] = text
+			translations = updated
+		}
+	}
+	if newTranslations != nil {
+		normalized, err := newTranslations.Normalize(defaultLocale)
+		if err != nil {
+			return "", nil, err
+		}
+		translations = normalized
+		if normalized != nil {
+			text = normalized[CanonicalTag(defaultLocale)]
+		}
+	}
+	return text, translations, nil
+}
+
+// IsInvalidText reports whether err rejects translations
+func IsInvalidText(err error) bool {
+	return errors.Is(err, ErrInvalidLanguage) || errors.Is(err, ErrMissingDefaultLocale)
+}
+
+// Pick returns the text in the first of languages it has, falling back from a regional
+// tag to its language and from a language to any of its regional variants. ok is false
+// when it has none of them, for the caller to fall back to the default text.
+func (t Text) Pick(languages []string) (text string, ok bool) {
+	for _, language := range languages {
+		if text, ok = t[language]; ok {
+			return text, true
+		}
+		base := BaseLanguage(language)
+		if text, ok = t[base]; ok {
+			return text, true
+		}
+
+		var variants []string
+		for tag := range t {
+			if BaseLanguage(tag) == base {
+				variants = append(variants, tag)
+			}
+		}
+		if len(variants) > 0 {
+			sort.Strings(variants)
+			return t[variants[0]], true
+		}
+	}
+	return "", false
+}
+
+// Localize returns the text in the best of languages, or fallback, the text in the
+// default locale of the festival
+func (t Text) Localize(languages []string, fallback string) string {
+	if text, ok := t.Pick(languages); ok {
+		return text
+	}
+	return fallback
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
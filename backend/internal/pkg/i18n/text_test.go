@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCanonicalTag tests formatting of content language tags
+func TestCanonicalTag(t *testing.T) {
+	tests := map[string]string{
+		"fr":      "fr",
+		"fr_be":   "fr-BE",
+		" EN-us":  "en-US",
+		"es-419":  "es-419",
+		"ast":     "ast",
+		"f":       "",
+		"fr-B":    "",
+		"fr-BE-x": "",
+		"12":      "",
+		"":        "",
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, CanonicalTag(input), input)
+	}
+}
+
+// TestNegotiate tests the order of the languages asked by a request
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, []string{"es", "nl-BE", "fr"}, Negotiate("es", "fr;q=0.8, nl-be, es;q=0.1"))
+	assert.Equal(t, []string{"de"}, Negotiate("", "de, *;q=0.5"))
+	assert.Empty(t, Negotiate("", ""))
+}
+
+// TestTextNormalize tests validation of translations against the default locale
+func TestTextNormalize(t *testing.T) {
+	text, err := Text{"EN": " Beer ", "fr_be": "Bière", "nl": " "}.Normalize("en")
+	require.NoError(t, err)
+	assert.Equal(t, Text{"en": "Beer", "fr-BE": "Bière"}, text)
+
+	_, err = Text{"fr": "Bière"}.Normalize("en")
+	assert.ErrorIs(t, err, ErrMissingDefaultLocale)
+	_, err = Text{"en": "Beer", "beer": "Beer"}.Normalize("en")
+	assert.ErrorIs(t, err, ErrInvalidLanguage)
+
+	text, err = Text{"en": ""}.Normalize("en")
+	require.NoError(t, err)
+	assert.Nil(t, text, "blank translations are no translations")
+}
+
+// TestTextLocalize tests the fallback chain of translations
+func TestTextLocalize(t *testing.T) {
+	text := Text{"en": "Fries", "fr-BE": "Frites", "nl": "Friet"}
+
+	assert.Equal(t, "Frites", text.Localize([]string{"fr-BE"}, "Fries"))
+	assert.Equal(t, "Frites", text.Localize([]string{"fr"}, "Fries"), "any regional variant of the language")
+	assert.Equal(t, "Friet", text.Localize([]string{"nl-BE"}, "Fries"), "regional tag falls back to its language")
+	assert.Equal(t, "Friet", text.Localize([]string{"es", "nl"}, "Fries"), "next language asked")
+	assert.Equal(t, "Fries", text.Localize([]string{"es"}, "Fries"), "default text")
+	assert.Equal(t, "Fries", Text(nil).Localize([]string{"fr"}, "Fries"))
+}
+
+// TestUpdateText tests keeping a text and its translations in step
+func TestUpdateText(t *testing.T) {
+	name := "Lager"
+	text, translations, err := UpdateText("en", "Beer", Text{"en": "Beer", "fr": "Bière"}, &name, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Lager", text)
+	assert.Equal(t, Text{"en": "Lager", "fr": "Bière"}, translations)
+
+	text, translations, err = UpdateText("fr", "Bière", nil, &name, Text{"fr": "Blonde", "nl": "Pils"})
+	require.NoError(t, err)
+	assert.Equal(t, "Blonde", text, "translations win over the text")
+	assert.Equal(t, Text{"fr": "Blonde", "nl": "Pils"}, translations)
+
+	text, translations, err = UpdateText("en", "Beer", Text{"en": "Beer"}, nil, Text{})
+	require.NoError(t, err)
+	assert.Equal(t, "Beer", text)
+	assert.Nil(t, translations, "empty translations clear them")
+
+	_, _, err = UpdateText("en", "Beer", nil, nil, Text{"nl": "Bier"})
+	assert.True(t, IsInvalidText(err))
+}
@@ -0,0 +1,347 @@
+// Package openapi models the subset of OpenAPI 3.0 the API is described with. It
+// generates schemas from Go types, validates documents and JSON payloads against
+// them, and serves mock responses for client development.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the OpenAPI version of the documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `yaml:"openapi" json:"openapi"`
+	Info       Info                  `yaml:"info" json:"info"`
+	Servers    []Server              `yaml:"servers,omitempty" json:"servers,omitempty"`
+	Tags       []Tag                 `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Security   []map[string][]string `yaml:"security,omitempty" json:"security,omitempty"`
+	Paths      map[string]PathItem   `yaml:"paths" json:"paths"`
+	Components Components            `yaml:"components" json:"components"`
+}
+
+type Info struct {
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Version     string `yaml:"version" json:"version"`
+}
+
+type Server struct {
+	URL         string `yaml:"url" json:"url"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// PathItem maps the lowercase HTTP methods of a path to their operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `yaml:"operationId" json:"operationId"`
+	Summary     string               `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string               `yaml:"description,omitempty" json:"description,omitempty"`
+	Tags        []string             `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Parameters  []Parameter          `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	RequestBody *RequestBody         `yaml:"requestBody,omitempty" json:"requestBody,omitempty"`
+	Responses   map[string]*Response `yaml:"responses" json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `yaml:"name" json:"name"`
+	In          string  `yaml:"in" json:"in"` // path, query or header
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Schema      *Schema `yaml:"schema" json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `yaml:"required,omitempty" json:"required,omitempty"`
+	Content  map[string]MediaType `yaml:"content" json:"content"`
+}
+
+type Response struct {
+	Description string               `yaml:"description" json:"description"`
+	Content     map[string]MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema" json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `yaml:"schemas,omitempty" json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `yaml:"securitySchemes,omitempty" json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `yaml:"type" json:"type"`
+	Scheme       string `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	BearerFormat string `yaml:"bearerFormat,omitempty" json:"bearerFormat,omitempty"`
+	Description  string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Schema is a JSON schema as restricted by OpenAPI 3.0. An empty schema accepts any value.
+type Schema struct {
+	Ref                  string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Type                 string             `yaml:"type,omitempty" json:"type,omitempty"`
+	Format               string             `yaml:"format,omitempty" json:"format,omitempty"`
+	Description          string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Nullable             bool               `yaml:"nullable,omitempty" json:"nullable,omitempty"`
+	Enum                 []string           `yaml:"enum,omitempty" json:"enum,omitempty"`
+	AllOf                []*Schema          `yaml:"allOf,omitempty" json:"allOf,omitempty"`
+	Properties           map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required             []string           `yaml:"required,omitempty" json:"required,omitempty"`
+	Items                *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+	AdditionalProperties *Schema            `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+	Minimum              *float64           `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum              *float64           `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	MinLength            *int               `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength            *int               `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+	MinItems             *int               `yaml:"minItems,omitempty" json:"minItems,omitempty"`
+}
+
+// Parse reads a YAML or JSON document
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	return &doc, nil
+}
+
+// YAML returns the document as YAML, with its maps sorted so the output is stable
+func (d *Document) YAML() ([]byte, error) {
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d); err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+var (
+	methods       = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true}
+	statusPattern = regexp.MustCompile(`^([1-5][0-9][0-9]|default)$`)
+	paramPattern  = regexp.MustCompile(`\{([^{}/]+)\}`)
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// Validate checks the structure of the document: the operations are identified
+// uniquely, declare the parameters of their path and a response, and every schema
+// reference resolves
+func (d *Document) Validate() error {
+	var errs []error
+	if !strings.HasPrefix(d.OpenAPI, "3.0.") {
+		errs = append(errs, fmt.Errorf("unsupported OpenAPI version %q", d.OpenAPI))
+	}
+	if d.Info.Title == "" || d.Info.Version == "" {
+		errs = append(errs, errors.New("info.title and info.version are required"))
+	}
+
+	operationIDs := make(map[string]string)
+	for _, path := range sortedKeys(d.Paths) {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("path %q must start with /", path))
+		}
+		templateParams := make(map[string]bool)
+		for _, match := range paramPattern.FindAllStringSubmatch(path, -1) {
+			if templateParams[match[1]] {
+				errs = append(errs, fmt.Errorf("path %q repeats parameter %q", path, match[1]))
+			}
+			templateParams[match[1]] = true
+		}
+
+		for _, method := range sortedKeys(d.Paths[path]) {
+			op := d.Paths[path][method]
+			where := strings.ToUpper(method) + " " + path
+			if !methods[method] {
+				errs = append(errs, fmt.Errorf("%s: unknown method", where))
+			}
+			if op == nil {
+				errs = append(errs, fmt.Errorf("%s: empty operation", where))
+				continue
+			}
+
+			switch other, dup := operationIDs[op.OperationID]; {
+			case op.OperationID == "":
+				errs = append(errs, fmt.Errorf("%s: operationId is required", where))
+			case dup:
+				errs = append(errs, fmt.Errorf("%s: operationId %q is already used by %s", where, op.OperationID, other))
+			default:
+				operationIDs[op.OperationID] = where
+			}
+
+			declared := make(map[string]bool)
+			for _, param := range op.Parameters {
+				switch param.In {
+				case "path":
+					declared[param.Name] = true
+					if !templateParams[param.Name] {
+						errs = append(errs, fmt.Errorf("%s: path parameter %q is not in the path", where, param.Name))
+					}
+					if !param.Required {
+						errs = append(errs, fmt.Errorf("%s: path parameter %q must be required", where, param.Name))
+					}
+				case "query", "header":
+				default:
+					errs = append(errs, fmt.Errorf("%s: parameter %q has unknown location %q", where, param.Name, param.In))
+				}
+				errs = append(errs, d.checkSchema(where+" parameter "+param.Name, param.Schema)...)
+			}
+			for name := range templateParams {
+				if !declared[name] {
+					errs = append(errs, fmt.Errorf("%s: path parameter %q is not declared", where, name))
+				}
+			}
+
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					errs = append(errs, d.checkSchema(where+" request body", media.Schema)...)
+				}
+			}
+
+			if len(op.Responses) == 0 {
+				errs = append(errs, fmt.Errorf("%s: no response declared", where))
+			}
+			for _, status := range sortedKeys(op.Responses) {
+				if !statusPattern.MatchString(status) {
+					errs = append(errs, fmt.Errorf("%s: invalid response status %q", where, status))
+				}
+				if op.Responses[status] == nil {
+					continue
+				}
+				for _, media := range op.Responses[status].Content {
+					errs = append(errs, d.checkSchema(where+" response "+status, media.Schema)...)
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(d.Components.Schemas) {
+		errs = append(errs, d.checkSchema("schema "+name, d.Components.Schemas[name])...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkSchema checks that the references of a schema resolve
+func (d *Document) checkSchema(where string, schema *Schema) []error {
+	if schema == nil {
+		return nil
+	}
+
+	var errs []error
+	if schema.Ref != "" {
+		if _, err := d.resolve(schema.Ref); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+		}
+		// Referenced schemas are checked once, as components
+		return errs
+	}
+
+	for _, name := range sortedKeys(schema.Properties) {
+		errs = append(errs, d.checkSchema(where+"."+name, schema.Properties[name])...)
+	}
+	for _, name := range schema.Required {
+		if schema.Properties[name] == nil {
+			errs = append(errs, fmt.Errorf("%s: required property %q is not defined", where, name))
+		}
+	}
+	for _, sub := range schema.AllOf {
+		errs = append(errs, d.checkSchema(where, sub)...)
+	}
+	errs = append(errs, d.checkSchema(where+"[]", schema.Items)...)
+	errs = append(errs, d.checkSchema(where+"{}", schema.AdditionalProperties)...)
+	if schema.Type == "array" && schema.Items == nil {
+		errs = append(errs, fmt.Errorf("%s: array without items", where))
+	}
+	return errs
+}
+
+// resolve returns the component schema of a reference
+func (d *Document) resolve(ref string) (*Schema, error) {
+	name, ok := strings.CutPrefix(ref, schemaRefPrefix)
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q", ref)
+	}
+	schema := d.Components.Schemas[name]
+	if schema == nil {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return schema, nil
+}
+
+// Operation returns the operation serving a request path, relative to the server
+// URL, and its path template. Literal segments take precedence over parameters,
+// so /stands/nearby is not served by /stands/{id}.
+func (d *Document) Operation(method, path string) (*Operation, string) {
+	method = strings.ToLower(method)
+	segments := splitPath(path)
+
+	var (
+		best         *Operation
+		bestTemplate string
+		bestLiterals = -1
+	)
+	for _, template := range sortedKeys(d.Paths) {
+		op := d.Paths[template][method]
+		if op == nil {
+			continue
+		}
+		literals, ok := matchTemplate(splitPath(template), segments)
+		if ok && literals > bestLiterals {
+			best, bestTemplate, bestLiterals = op, template, literals
+		}
+	}
+	return best, bestTemplate
+}
+
+// matchTemplate tells whether the segments of a path match a template, and with
+// how many literal segments
+func matchTemplate(template, segments []string) (int, bool) {
+	if len(template) != len(segments) {
+		return 0, false
+	}
+	literals := 0
+	for i, part := range template {
+		if paramPattern.MatchString(part) && strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if part != segments[i] {
+			return 0, false
+		}
+		literals++
+	}
+	return literals, true
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
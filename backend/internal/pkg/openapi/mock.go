@@ -0,0 +1,198 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Values of the generated examples, valid for their format
+const (
+	ExampleUUID     = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	ExampleDateTime = "2026-07-10T18:30:00Z"
+)
+
+// Example returns a value valid against a schema: the first enum value, the lower
+// bound of numbers and every property of objects
+func (d *Document) Example(schema *Schema) any {
+	return d.example(schema, 0)
+}
+
+func (d *Document) example(schema *Schema, depth int) any {
+	if schema == nil || depth > maxDepth {
+		return nil
+	}
+	if schema.Ref != "" {
+		resolved, err := d.resolve(schema.Ref)
+		if err != nil {
+			return nil
+		}
+		return d.example(resolved, depth+1)
+	}
+	if len(schema.AllOf) > 0 {
+		return d.example(schema.AllOf[0], depth+1)
+	}
+
+	switch schema.Type {
+	case "object":
+		object := make(map[string]any, len(schema.Properties))
+		for name, property := range schema.Properties {
+			object[name] = d.example(property, depth+1)
+		}
+		return object
+	case "array":
+		return []any{d.example(schema.Items, depth+1)}
+	case "string":
+		return exampleString(schema)
+	case "integer", "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		if schema.Maximum != nil && *schema.Maximum < 1 {
+			return *schema.Maximum
+		}
+		return 1
+	case "boolean":
+		return true
+	default:
+		return nil
+	}
+}
+
+func exampleString(schema *Schema) string {
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	switch schema.Format {
+	case "uuid":
+		return ExampleUUID
+	case "date-time":
+		return ExampleDateTime
+	case "email":
+		return "user@example.com"
+	case "uri":
+		return "https://example.com"
+	}
+
+	s := "string"
+	if schema.MinLength != nil && len(s) < *schema.MinLength {
+		s += strings.Repeat("x", *schema.MinLength-len(s))
+	}
+	if schema.MaxLength != nil && len(s) > *schema.MaxLength {
+		s = s[:*schema.MaxLength]
+	}
+	return s
+}
+
+// Mock serves the operations of a document with examples of their responses, for
+// clients to be developed without the API. Requests are matched below basePath and
+// their JSON body is validated against the operation. The response is the first
+// success of the operation, or the one of the status requested with the
+// `Prefer: code=404` header. The document itself is served at /openapi.json.
+type Mock struct {
+	doc      *Document
+	basePath string
+}
+
+// NewMock returns a mock of the operations of doc served below basePath
+func NewMock(doc *Document, basePath string) *Mock {
+	return &Mock{doc: doc, basePath: strings.TrimRight(basePath, "/")}
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The mock is called from development servers of other origins
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Prefer, X-Request-ID")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.URL.Path == "/openapi.json" {
+		writeJSON(w, http.StatusOK, m.doc)
+		return
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, m.basePath)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "No operation matches "+r.Method+" "+r.URL.Path)
+		return
+	}
+	op, _ := m.doc.Operation(r.Method, path)
+	if op == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "No operation matches "+r.Method+" "+r.URL.Path)
+		return
+	}
+
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok {
+			body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "INVALID_BODY", "Failed to read request body")
+				return
+			}
+			if len(body) > 0 || op.RequestBody.Required {
+				if err := m.doc.ValidateJSON(media.Schema, body); err != nil {
+					writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+					return
+				}
+			}
+		}
+	}
+
+	status, response := m.response(op, r.Header.Get("Prefer"))
+	if response == nil {
+		writeError(w, http.StatusNotImplemented, "NO_RESPONSE", "The operation declares no such response")
+		return
+	}
+	media, ok := response.Content["application/json"]
+	if !ok {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, m.doc.Example(media.Schema))
+}
+
+// response picks the response to serve: the status preferred by the client, else
+// the first success
+func (m *Mock) response(op *Operation, prefer string) (int, *Response) {
+	for _, part := range strings.Split(prefer, ",") {
+		if code, ok := strings.CutPrefix(strings.TrimSpace(part), "code="); ok {
+			status, err := strconv.Atoi(code)
+			if err != nil {
+				return 0, nil
+			}
+			return status, op.Responses[code]
+		}
+	}
+
+	statuses := make([]string, 0, len(op.Responses))
+	for status := range op.Responses {
+		if strings.HasPrefix(status, "2") {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return 0, nil
+	}
+	sort.Strings(statuses)
+	status, _ := strconv.Atoi(statuses[0])
+	return status, op.Responses[statuses[0]]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error with the envelope of the API errors
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
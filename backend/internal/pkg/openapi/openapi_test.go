@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type itemStatus string
+
+type item struct {
+	ID        uuid.UUID  `json:"id"`
+	Status    itemStatus `json:"status"`
+	Tags      []string   `json:"tags"`
+	ParentID  *uuid.UUID `json:"parentId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type createItemRequest struct {
+	Name     string `json:"name" binding:"required,min=2,max=40"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+	Note     string `json:"note"`
+}
+
+func testDocument(t *testing.T) *Document {
+	t.Helper()
+
+	schemas := NewSchemas()
+	schemas.Enum(itemStatus(""), "OPEN", "CLOSED")
+	itemSchema := schemas.Of(item{})
+	request := schemas.Of(createItemRequest{})
+
+	envelope := &Schema{Type: "object", Properties: map[string]*Schema{"data": itemSchema}, Required: []string{"data"}}
+	errorSchema := &Schema{Type: "object", Properties: map[string]*Schema{"error": {Type: "object"}}, Required: []string{"error"}}
+	jsonContent := func(schema *Schema) map[string]MediaType {
+		return map[string]MediaType{"application/json": {Schema: schema}}
+	}
+	idParam := Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "Items", Version: "1.0.0"},
+		Paths: map[string]PathItem{
+			"/items": {
+				"post": {
+					OperationID: "createItem",
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(request)},
+					Responses: map[string]*Response{
+						"201": {Description: "Created", Content: jsonContent(envelope)},
+						"400": {Description: "Bad Request", Content: jsonContent(errorSchema)},
+					},
+				},
+			},
+			"/items/{id}": {
+				"get": {
+					OperationID: "getItem",
+					Parameters:  []Parameter{idParam},
+					Responses: map[string]*Response{
+						"200": {Description: "OK", Content: jsonContent(envelope)},
+						"404": {Description: "Not Found", Content: jsonContent(errorSchema)},
+					},
+				},
+			},
+		},
+		Components: Components{Schemas: schemas.Components()},
+	}
+	require.NoError(t, doc.Validate())
+	return doc
+}
+
+func TestSchemas_Of(t *testing.T) {
+	doc := testDocument(t)
+
+	itemSchema := doc.Components.Schemas["item"]
+	require.NotNil(t, itemSchema)
+	assert.ElementsMatch(t, []string{"id", "status", "tags", "createdAt"}, itemSchema.Required)
+	assert.Equal(t, []string{"OPEN", "CLOSED"}, itemSchema.Properties["status"].Enum)
+	assert.True(t, itemSchema.Properties["tags"].Nullable)
+	assert.Equal(t, "date-time", itemSchema.Properties["createdAt"].Format)
+
+	request := doc.Components.Schemas["createItemRequest"]
+	require.NotNil(t, request)
+	assert.ElementsMatch(t, []string{"name", "quantity"}, request.Required)
+	assert.Equal(t, 2, *request.Properties["name"].MinLength)
+	assert.Equal(t, 40, *request.Properties["name"].MaxLength)
+	assert.Equal(t, 1.0, *request.Properties["quantity"].Minimum)
+}
+
+func TestDocument_Validate(t *testing.T) {
+	doc := testDocument(t)
+	doc.Paths["/items/{itemId}/notes"] = PathItem{
+		"get": {
+			OperationID: "getItem",
+			Responses: map[string]*Response{
+				"200": {Description: "OK", Content: map[string]MediaType{
+					"application/json": {Schema: &Schema{Ref: "#/components/schemas/missing"}},
+				}},
+			},
+		},
+	}
+
+	err := doc.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `operationId "getItem" is already used`)
+	assert.Contains(t, err.Error(), "itemId")
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestDocument_ValidateResponse(t *testing.T) {
+	doc := testDocument(t)
+	path := "/items/" + uuid.NewString()
+
+	valid := `{"data":{"id":"` + uuid.NewString() + `","status":"OPEN","tags":null,"createdAt":"2026-07-10T18:30:00Z"}}`
+	assert.NoError(t, doc.ValidateResponse(http.MethodGet, path, http.StatusOK, []byte(valid)))
+
+	invalidStatus := strings.Replace(valid, "OPEN", "LOST", 1)
+	assert.ErrorContains(t, doc.ValidateResponse(http.MethodGet, path, http.StatusOK, []byte(invalidStatus)), "LOST")
+
+	missingID := `{"data":{"status":"OPEN","tags":[],"createdAt":"2026-07-10T18:30:00Z"}}`
+	assert.ErrorContains(t, doc.ValidateResponse(http.MethodGet, path, http.StatusOK, []byte(missingID)), `"id"`)
+
+	assert.ErrorContains(t, doc.ValidateResponse(http.MethodGet, path, http.StatusConflict, []byte(`{}`)), "undeclared")
+}
+
+func TestMock(t *testing.T) {
+	doc := testDocument(t)
+	mock := NewMock(doc, "/api/v1")
+
+	serve := func(method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		mock.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/v1/items/"+ExampleUUID, "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, doc.ValidateResponse(http.MethodGet, "/items/"+ExampleUUID, w.Code, w.Body.Bytes()))
+
+	w = serve(http.MethodGet, "/api/v1/items/"+ExampleUUID, "", http.Header{"Prefer": {"code=404"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(http.MethodPost, "/api/v1/items", `{"name":"Cup","quantity":2}`, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = serve(http.MethodPost, "/api/v1/items", `{"name":"C"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "VALIDATION_ERROR")
+
+	w = serve(http.MethodDelete, "/api/v1/items/"+ExampleUUID, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	doc := testDocument(t)
+	data, err := doc.YAML()
+	require.NoError(t, err)
+
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	require.NoError(t, parsed.Validate())
+
+	again, err := parsed.YAML()
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
@@ -0,0 +1,218 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxDepth bounds the nesting of validated values and generated examples
+const maxDepth = 32
+
+// ValidateResponse checks a JSON response of a request against the response the
+// document declares for its status, or its default response
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) error {
+	op, template := d.Operation(method, path)
+	if op == nil {
+		return fmt.Errorf("no operation for %s %s", method, path)
+	}
+
+	response := op.Responses[strconv.Itoa(status)]
+	if response == nil {
+		response = op.Responses["default"]
+	}
+	if response == nil {
+		return fmt.Errorf("%s %s: undeclared response status %d", method, template, status)
+	}
+
+	media, ok := response.Content["application/json"]
+	if !ok || media.Schema == nil {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s %s: response %d declares no content", method, template, status)
+		}
+		return nil
+	}
+
+	if err := d.ValidateJSON(media.Schema, body); err != nil {
+		return fmt.Errorf("%s %s: response %d: %w", method, template, status, err)
+	}
+	return nil
+}
+
+// ValidateJSON checks a JSON document against a schema
+func (d *Document) ValidateJSON(schema *Schema, data []byte) error {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return d.ValidateValue(schema, value)
+}
+
+// ValidateValue checks a value decoded from JSON with UseNumber against a schema.
+// Properties the schema does not define are allowed, so clients accept additions.
+func (d *Document) ValidateValue(schema *Schema, value any) error {
+	return d.validate(schema, value, "$", 0)
+}
+
+func (d *Document) validate(schema *Schema, value any, at string, depth int) error {
+	if schema == nil {
+		return nil
+	}
+	if depth > maxDepth {
+		return fmt.Errorf("%s: nested too deeply", at)
+	}
+	if schema.Ref != "" {
+		resolved, err := d.resolve(schema.Ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", at, err)
+		}
+		return d.validate(resolved, value, at, depth+1)
+	}
+
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.AllOf) == 0) {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", at)
+	}
+
+	for _, sub := range schema.AllOf {
+		if err := d.validate(sub, value, at, depth+1); err != nil {
+			return err
+		}
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "object":
+		return d.validateObject(schema, value, at, depth)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return typeError(at, "array", value)
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			return fmt.Errorf("%s: must have at least %d items", at, *schema.MinItems)
+		}
+		for i, item := range items {
+			if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return typeError(at, "string", value)
+		}
+		return validateString(schema, s, at)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return typeError(at, schema.Type, value)
+		}
+		n, err := number.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %s", at, number)
+		}
+		if schema.Type == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("%s: %s is not an integer", at, number)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return fmt.Errorf("%s: %s is less than %v", at, number, *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			return fmt.Errorf("%s: %s is greater than %v", at, number, *schema.Maximum)
+		}
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(at, "boolean", value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", at, schema.Type)
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, value any, at string, depth int) error {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return typeError(at, "object", value)
+	}
+
+	var errs []error
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s: missing required property %q", at, name))
+		}
+	}
+	for _, name := range sortedKeys(object) {
+		property := schema.Properties[name]
+		if property == nil {
+			property = schema.AdditionalProperties
+		}
+		if err := d.validate(property, object[name], at+"."+name, depth+1); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateString(schema *Schema, s, at string) error {
+	if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+		return fmt.Errorf("%s: %q is not one of %s", at, s, strings.Join(schema.Enum, ", "))
+	}
+	if schema.MinLength != nil && len([]rune(s)) < *schema.MinLength {
+		return fmt.Errorf("%s: shorter than %d characters", at, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && len([]rune(s)) > *schema.MaxLength {
+		return fmt.Errorf("%s: longer than %d characters", at, *schema.MaxLength)
+	}
+
+	switch schema.Format {
+	case "uuid":
+		if _, err := uuid.Parse(s); err != nil {
+			return fmt.Errorf("%s: %q is not a UUID", at, s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return fmt.Errorf("%s: %q is not an RFC 3339 date-time", at, s)
+		}
+	}
+	return nil
+}
+
+func typeError(at, expected string, value any) error {
+	actual := "object"
+	switch value.(type) {
+	case []any:
+		actual = "array"
+	case string:
+		actual = "string"
+	case json.Number:
+		actual = "number"
+	case bool:
+		actual = "boolean"
+	}
+	return fmt.Errorf("%s: expected %s, got %s", at, expected, actual)
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
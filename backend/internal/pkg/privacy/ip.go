@@ -0,0 +1,45 @@
+// Package privacy anonymizes the personal data written to logs, such as the IP
+// addresses of the clients.
+package privacy
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// Prefixes kept by AnonymizeIP: the last octet of an IPv4 address is dropped, and
+// the last 80 bits of an IPv6 address, leaving the network of the site
+const (
+	IPv4PrefixLength = 24
+	IPv6PrefixLength = 48
+)
+
+// anonymizeLogIPs is set from config at startup
+var anonymizeLogIPs atomic.Bool
+
+// SetLogIPAnonymization sets whether LogIP anonymizes the addresses it is given
+func SetLogIPAnonymization(enabled bool) {
+	anonymizeLogIPs.Store(enabled)
+}
+
+// LogIP returns the address to write to the logs, anonymized when enabled
+func LogIP(ip string) string {
+	if !anonymizeLogIPs.Load() {
+		return ip
+	}
+	return AnonymizeIP(ip)
+}
+
+// AnonymizeIP truncates an IP address to its network, e.g. 203.0.113.42 to
+// 203.0.113.0 and 2001:db8:85a3::8a2e:370:7334 to 2001:db8:85a3::. Invalid addresses
+// are dropped. It matches the anonymize_ip SQL function of the retention sweep.
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(IPv4PrefixLength, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(IPv6PrefixLength, 128)).String()
+}
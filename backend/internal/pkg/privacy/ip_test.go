@@ -0,0 +1,35 @@
+package privacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.42", "203.0.113.0"},
+		{"10.0.0.1", "10.0.0.0"},
+		{"2001:db8:85a3::8a2e:370:7334", "2001:db8:85a3::"},
+		{"::1", "::"},
+		{"", ""},
+		{"not-an-ip", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, AnonymizeIP(tt.ip), tt.ip)
+	}
+}
+
+func TestLogIP(t *testing.T) {
+	defer SetLogIPAnonymization(false)
+
+	SetLogIPAnonymization(false)
+	assert.Equal(t, "203.0.113.42", LogIP("203.0.113.42"))
+
+	SetLogIPAnonymization(true)
+	assert.Equal(t, "203.0.113.0", LogIP("203.0.113.42"))
+}
@@ -0,0 +1,109 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+)
+
+// FieldError describes why a field of the request failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// Report request fields by their JSON (or form) name rather than the Go field name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// Locale returns the locale of the request, as resolved by the Locale middleware
+// or from the Accept-Language header when the middleware did not run
+func Locale(c *gin.Context) string {
+	if locale := c.GetString("locale"); locale != "" {
+		return locale
+	}
+	return i18n.Resolve("", c.GetHeader("Accept-Language"))
+}
+
+// localize translates the message of an error code when the request is not in the
+// default locale; handlers write specific English messages, so they are kept as-is
+// in English and for codes without a catalog entry
+func localize(c *gin.Context, code, message string) string {
+	locale := Locale(c)
+	if locale == i18n.Default {
+		return message
+	}
+	if translated, ok := i18n.Lookup(locale, "error."+code); ok {
+		return translated
+	}
+	return message
+}
+
+// ValidationFailed sends a 400 response for a request that could not be bound,
+// with a localized message for each field that failed validation
+func ValidationFailed(c *gin.Context, err error) {
+	locale := Locale(c)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// Malformed body (invalid JSON, wrong types): no field rules to report
+		message := "Invalid request body"
+		if locale != i18n.Default {
+			message = i18n.T(locale, "error.INVALID_BODY")
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{Code: "VALIDATION_ERROR", Message: message, Details: err.Error()},
+		})
+		return
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: fieldMessage(locale, fieldErr.Tag(), fieldErr.Param()),
+		})
+	}
+
+	BadRequest(c, "VALIDATION_ERROR", "Invalid request body", fields)
+}
+
+// fieldPath returns the path of the field below the request struct, e.g. "items[0].quantity"
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fieldErr.Field()
+}
+
+// fieldMessage returns the localized message of a validation rule
+func fieldMessage(locale, rule, param string) string {
+	params := i18n.Params{"param": strings.ReplaceAll(param, " ", ", ")}
+	if _, ok := i18n.Lookup(i18n.Default, "validation."+rule); ok {
+		return i18n.T(locale, "validation."+rule, params)
+	}
+	return i18n.T(locale, "validation.default", params)
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mimi6060/festivals/backend/internal/pkg/i18n"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/rs/zerolog/log"
 )
 
@@ -47,31 +49,31 @@ func NoContent(c *gin.Context) {
 
 func BadRequest(c *gin.Context, code, message string, details interface{}) {
 	c.JSON(http.StatusBadRequest, ErrorResponse{
-		Error: ErrorDetail{Code: code, Message: message, Details: details},
+		Error: ErrorDetail{Code: code, Message: localize(c, code, message), Details: details},
 	})
 }
 
 func Unauthorized(c *gin.Context, message string) {
 	c.JSON(http.StatusUnauthorized, ErrorResponse{
-		Error: ErrorDetail{Code: "UNAUTHORIZED", Message: message},
+		Error: ErrorDetail{Code: "UNAUTHORIZED", Message: localize(c, "UNAUTHORIZED", message)},
 	})
 }
 
 func Forbidden(c *gin.Context, message string) {
 	c.JSON(http.StatusForbidden, ErrorResponse{
-		Error: ErrorDetail{Code: "FORBIDDEN", Message: message},
+		Error: ErrorDetail{Code: "FORBIDDEN", Message: localize(c, "FORBIDDEN", message)},
 	})
 }
 
 func NotFound(c *gin.Context, message string) {
 	c.JSON(http.StatusNotFound, ErrorResponse{
-		Error: ErrorDetail{Code: "NOT_FOUND", Message: message},
+		Error: ErrorDetail{Code: "NOT_FOUND", Message: localize(c, "NOT_FOUND", message)},
 	})
 }
 
 func Conflict(c *gin.Context, code, message string) {
 	c.JSON(http.StatusConflict, ErrorResponse{
-		Error: ErrorDetail{Code: code, Message: message},
+		Error: ErrorDetail{Code: code, Message: localize(c, code, message)},
 	})
 }
 
@@ -90,7 +92,7 @@ func InternalError(c *gin.Context, internalMessage string) {
 		Str("request_id", requestID).
 		Str("path", c.Request.URL.Path).
 		Str("method", c.Request.Method).
-		Str("client_ip", c.ClientIP()).
+		Str("client_ip", privacy.LogIP(c.ClientIP())).
 		Str("error", internalMessage).
 		Msg("Internal server error")
 
@@ -98,7 +100,7 @@ func InternalError(c *gin.Context, internalMessage string) {
 	c.JSON(http.StatusInternalServerError, ErrorResponse{
 		Error: ErrorDetail{
 			Code:    "INTERNAL_ERROR",
-			Message: "An unexpected error occurred. Please try again later.",
+			Message: i18n.T(Locale(c), "error.INTERNAL_ERROR"),
 		},
 	})
 }
@@ -115,7 +117,7 @@ func InternalErrorWithCode(c *gin.Context, code, internalMessage string) {
 		Str("request_id", requestID).
 		Str("path", c.Request.URL.Path).
 		Str("method", c.Request.Method).
-		Str("client_ip", c.ClientIP()).
+		Str("client_ip", privacy.LogIP(c.ClientIP())).
 		Str("error_code", code).
 		Str("error", internalMessage).
 		Msg("Internal server error")
@@ -123,7 +125,7 @@ func InternalErrorWithCode(c *gin.Context, code, internalMessage string) {
 	c.JSON(http.StatusInternalServerError, ErrorResponse{
 		Error: ErrorDetail{
 			Code:    code,
-			Message: "An unexpected error occurred. Please try again later.",
+			Message: i18n.T(Locale(c), "error.INTERNAL_ERROR"),
 		},
 	})
 }
@@ -144,7 +146,7 @@ func ServiceUnavailable(c *gin.Context, internalMessage string) {
 	c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 		Error: ErrorDetail{
 			Code:    "SERVICE_UNAVAILABLE",
-			Message: "The service is temporarily unavailable. Please try again later.",
+			Message: i18n.T(Locale(c), "error.SERVICE_UNAVAILABLE"),
 		},
 	})
 }
@@ -155,7 +157,7 @@ func TooManyRequests(c *gin.Context, retryAfterSeconds int) {
 	c.JSON(http.StatusTooManyRequests, ErrorResponse{
 		Error: ErrorDetail{
 			Code:    "RATE_LIMITED",
-			Message: "Too many requests. Please try again later.",
+			Message: i18n.T(Locale(c), "error.RATE_LIMITED"),
 			Details: map[string]interface{}{"retry_after_seconds": retryAfterSeconds},
 		},
 	})
@@ -168,21 +170,21 @@ func UnprocessableEntity(c *gin.Context, message string, fields map[string]strin
 		details["validation_errors"] = fields
 	}
 	c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
-		Error: ErrorDetail{Code: "VALIDATION_ERROR", Message: message, Details: details},
+		Error: ErrorDetail{Code: "VALIDATION_ERROR", Message: localize(c, "VALIDATION_ERROR", message), Details: details},
 	})
 }
 
 // Gone sends a 410 Gone response for deleted or expired resources
 func Gone(c *gin.Context, message string) {
 	c.JSON(http.StatusGone, ErrorResponse{
-		Error: ErrorDetail{Code: "RESOURCE_GONE", Message: message},
+		Error: ErrorDetail{Code: "RESOURCE_GONE", Message: localize(c, "RESOURCE_GONE", message)},
 	})
 }
 
 // PaymentRequired sends a 402 Payment Required response
 func PaymentRequired(c *gin.Context, message string) {
 	c.JSON(http.StatusPaymentRequired, ErrorResponse{
-		Error: ErrorDetail{Code: "PAYMENT_REQUIRED", Message: message},
+		Error: ErrorDetail{Code: "PAYMENT_REQUIRED", Message: localize(c, "PAYMENT_REQUIRED", message)},
 	})
 }
 
@@ -257,7 +259,7 @@ func SendError(c *gin.Context, err *StandardError) {
 		c.JSON(err.HTTPStatus, ErrorResponse{
 			Error: ErrorDetail{
 				Code:    string(err.Code),
-				Message: "An unexpected error occurred. Please try again later.",
+				Message: i18n.T(Locale(c), "error.INTERNAL_ERROR"),
 			},
 		})
 		return
@@ -266,7 +268,7 @@ func SendError(c *gin.Context, err *StandardError) {
 	c.JSON(err.HTTPStatus, ErrorResponse{
 		Error: ErrorDetail{
 			Code:    string(err.Code),
-			Message: err.Message,
+			Message: localize(c, string(err.Code), err.Message),
 			Details: err.Details,
 		},
 	})
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -488,3 +489,123 @@ func TestAllHTTPStatusCodes(t *testing.T) {
 		})
 	}
 }
+
+// TestLocalizedErrors tests error messages in the locale of the request
+func TestLocalizedErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		acceptLanguage  string
+		locale          string
+		handler         func(c *gin.Context)
+		expectedMessage string
+	}{
+		{
+			name:            "english keeps the handler message",
+			acceptLanguage:  "en-US",
+			handler:         func(c *gin.Context) { NotFound(c, "Ticket not found") },
+			expectedMessage: "Ticket not found",
+		},
+		{
+			name:            "known code is translated",
+			acceptLanguage:  "fr-BE,fr;q=0.9",
+			handler:         func(c *gin.Context) { NotFound(c, "Ticket not found") },
+			expectedMessage: "La ressource demandée est introuvable.",
+		},
+		{
+			name:            "unknown code keeps the handler message",
+			acceptLanguage:  "de",
+			handler:         func(c *gin.Context) { BadRequest(c, "INVALID_COLOR", "Invalid color", nil) },
+			expectedMessage: "Invalid color",
+		},
+		{
+			name:            "middleware locale wins over the header",
+			acceptLanguage:  "fr",
+			locale:          "nl",
+			handler:         func(c *gin.Context) { InternalError(c, "db down") },
+			expectedMessage: "Er is een onverwachte fout opgetreden. Probeer het later opnieuw.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.GET("/test", func(c *gin.Context) {
+				if tt.locale != "" {
+					c.Set("locale", tt.locale)
+				}
+				tt.handler(c)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var resp ErrorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMessage, resp.Error.Message)
+		})
+	}
+}
+
+// TestValidationFailed tests localized field validation errors
+func TestValidationFailed(t *testing.T) {
+	type item struct {
+		Quantity int `json:"quantity" binding:"gte=1"`
+	}
+	type request struct {
+		Email string `json:"email" binding:"required,email"`
+		Kind  string `json:"kind" binding:"oneof=TOP_UP PURCHASE"`
+		Items []item `json:"items" binding:"dive"`
+	}
+
+	router := setupTestRouter()
+	router.POST("/test", func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ValidationFailed(c, err)
+			return
+		}
+		NoContent(c)
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"email":"nope","kind":"GIFT","items":[{"quantity":0}]}`))
+		req.Header.Set("Accept-Language", "fr")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp struct {
+			Error struct {
+				Code    string       `json:"code"`
+				Message string       `json:"message"`
+				Details []FieldError `json:"details"`
+			} `json:"error"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "VALIDATION_ERROR", resp.Error.Code)
+		assert.Equal(t, "La requête contient des champs invalides.", resp.Error.Message)
+		assert.Equal(t, []FieldError{
+			{Field: "email", Rule: "email", Message: "doit être une adresse e-mail valide"},
+			{Field: "kind", Rule: "oneof", Message: "doit être l'une des valeurs : TOP_UP, PURCHASE"},
+			{Field: "items[0].quantity", Rule: "gte", Message: "doit être supérieur ou égal à 1"},
+		}, resp.Error.Details)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"email":`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "VALIDATION_ERROR", resp.Error.Code)
+		assert.Equal(t, "Invalid request body", resp.Error.Message)
+		assert.NotEmpty(t, resp.Error.Details)
+	})
+}
@@ -0,0 +1,158 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AlertGroupBy is what an alert rule counts events per
+type AlertGroupBy string
+
+const (
+	AlertGroupByIP       AlertGroupBy = "ip"
+	AlertGroupByUser     AlertGroupBy = "user"
+	AlertGroupByFestival AlertGroupBy = "festival"
+	AlertGroupByNone     AlertGroupBy = "none" // All matching events together
+)
+
+// AlertRule alerts when Threshold matching events of one group occur within Window.
+// It fires once per window, when the threshold is reached.
+type AlertRule struct {
+	ID         string
+	Name       string
+	EventTypes []SecurityEventType
+	Threshold  int
+	Window     time.Duration
+	GroupBy    AlertGroupBy
+	Channels   []string // Alert channels to notify, all of them when empty
+}
+
+// Matches reports whether an event counts towards the rule
+func (r AlertRule) Matches(event *SecurityEvent) bool {
+	for _, t := range r.EventTypes {
+		if t == event.Type {
+			return r.group(event) != ""
+		}
+	}
+	return false
+}
+
+// group returns the group an event is counted in, empty when the event has no
+// value for the grouping field
+func (r AlertRule) group(event *SecurityEvent) string {
+	switch r.GroupBy {
+	case AlertGroupByIP:
+		return event.IPAddress
+	case AlertGroupByUser:
+		return event.UserID
+	case AlertGroupByFestival:
+		return event.FestivalID
+	default:
+		return "all"
+	}
+}
+
+// AlertFiring is an alert a rule triggered
+type AlertFiring struct {
+	At    time.Time
+	Group string
+	Event SecurityEvent
+}
+
+// SimulateAlertRule replays events through a rule and returns the alerts it would
+// have triggered, counting events the same way the auditor does
+func SimulateAlertRule(rule AlertRule, events []SecurityEvent) []AlertFiring {
+	sorted := append([]SecurityEvent(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	type window struct {
+		start time.Time
+		count int
+	}
+	windows := make(map[string]*window)
+
+	var firings []AlertFiring
+	for i := range sorted {
+		event := &sorted[i]
+		if !rule.Matches(event) {
+			continue
+		}
+
+		group := rule.group(event)
+		w, ok := windows[group]
+		if !ok || !event.Timestamp.Before(w.start.Add(rule.Window)) {
+			w = &window{start: event.Timestamp}
+			windows[group] = w
+		}
+
+		w.count++
+		if w.count == rule.Threshold {
+			firings = append(firings, AlertFiring{At: event.Timestamp, Group: group, Event: *event})
+		}
+	}
+	return firings
+}
+
+// SetAlertRules replaces the static alert thresholds with rules. Session attacks and
+// privilege escalations are always alerted on. Passing nil restores the thresholds.
+func (a *SecurityAuditor) SetAlertRules(rules []AlertRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.alertRules = rules
+	a.rulesLoaded = rules != nil
+}
+
+// AddAlertChannel adds a named alert handler that alert rules can select
+func (a *SecurityAuditor) AddAlertChannel(name string, handler AlertHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.alertChannels[name] = handler
+}
+
+// AlertChannels returns the names of the alert channels
+func (a *SecurityAuditor) AlertChannels() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	names := make([]string, 0, len(a.alertChannels))
+	for name := range a.alertChannels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkAlertRules alerts on the rules an event reaches the threshold of
+func (a *SecurityAuditor) checkAlertRules(ctx context.Context, event *SecurityEvent, rules []AlertRule) {
+	for _, rule := range rules {
+		if !rule.Matches(event) {
+			continue
+		}
+		if a.reachesThreshold(ctx, rule.group(event), "rule:"+rule.ID, rule.Threshold, rule.Window) {
+			a.triggerAlertOn(ctx, event, rule.Name, rule.Channels)
+		}
+	}
+}
+
+// reachesThreshold counts an event and reports whether it is the one reaching the
+// threshold, so that a rule fires once per window
+func (a *SecurityAuditor) reachesThreshold(ctx context.Context, identifier, category string, threshold int, window time.Duration) bool {
+	if a.redisClient == nil {
+		return false
+	}
+
+	key := fmt.Sprintf("%salert:%s:%s", a.config.RedisKeyPrefix, category, identifier)
+	count, err := a.redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return false
+	}
+	if count == 1 {
+		a.redisClient.Expire(ctx, key, window)
+	}
+
+	return count == int64(threshold)
+}
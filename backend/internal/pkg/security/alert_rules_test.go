@@ -0,0 +1,81 @@
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAlertHandler struct {
+	alerts chan string
+}
+
+func (h *recordingAlertHandler) HandleAlert(ctx context.Context, event *SecurityEvent, threshold string) error {
+	h.alerts <- threshold
+	return nil
+}
+
+func authFailure(ip string, at time.Time) SecurityEvent {
+	return SecurityEvent{Type: EventAuthFailure, IPAddress: ip, Timestamp: at}
+}
+
+// TestSimulateAlertRule tests that a rule fires once per window and group
+func TestSimulateAlertRule(t *testing.T) {
+	start := time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+	rule := AlertRule{
+		ID:         "failed-auth",
+		EventTypes: []SecurityEventType{EventAuthFailure},
+		Threshold:  3,
+		Window:     5 * time.Minute,
+		GroupBy:    AlertGroupByIP,
+	}
+
+	events := []SecurityEvent{
+		authFailure("10.0.0.1", start),
+		authFailure("10.0.0.1", start.Add(time.Minute)),
+		authFailure("10.0.0.2", start.Add(time.Minute)),
+		{Type: EventAuthSuccess, IPAddress: "10.0.0.1", Timestamp: start.Add(2 * time.Minute)},
+		authFailure("10.0.0.1", start.Add(2*time.Minute)),
+		authFailure("10.0.0.1", start.Add(3*time.Minute)),
+		// A new window starts 5 minutes after the first event
+		authFailure("10.0.0.1", start.Add(5*time.Minute)),
+		authFailure("10.0.0.1", start.Add(6*time.Minute)),
+		authFailure("10.0.0.1", start.Add(7*time.Minute)),
+		// No IP address to group by
+		authFailure("", start.Add(7*time.Minute)),
+	}
+
+	firings := SimulateAlertRule(rule, events)
+	require.Len(t, firings, 2)
+	assert.Equal(t, start.Add(2*time.Minute), firings[0].At)
+	assert.Equal(t, "10.0.0.1", firings[0].Group)
+	assert.Equal(t, start.Add(7*time.Minute), firings[1].At)
+
+	rule.GroupBy = AlertGroupByNone
+	firings = SimulateAlertRule(rule, events)
+	require.Len(t, firings, 2)
+	assert.Equal(t, start.Add(time.Minute), firings[0].At, "events of every IP count together")
+}
+
+// TestTriggerAlertOn tests that an alert only reaches the channels of its rule
+func TestTriggerAlertOn(t *testing.T) {
+	auditor := &SecurityAuditor{alertChannels: make(map[string]AlertHandler)}
+	slack := &recordingAlertHandler{alerts: make(chan string, 2)}
+	webhook := &recordingAlertHandler{alerts: make(chan string, 2)}
+	auditor.AddAlertChannel("slack", slack)
+	auditor.AddAlertChannel("webhook", webhook)
+
+	assert.Equal(t, []string{"slack", "webhook"}, auditor.AlertChannels())
+
+	event := authFailure("10.0.0.1", time.Now())
+	auditor.triggerAlertOn(context.Background(), &event, "Brute force", []string{"slack"})
+	assert.Equal(t, "Brute force", <-slack.alerts)
+
+	auditor.triggerAlert(context.Background(), &event, "failed_auth")
+	assert.Equal(t, "failed_auth", <-slack.alerts)
+	assert.Equal(t, "failed_auth", <-webhook.alerts)
+	assert.Empty(t, webhook.alerts)
+}
@@ -9,12 +9,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"runtime"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mimi6060/festivals/backend/internal/pkg/privacy"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -42,6 +44,9 @@ const (
 	EventAuthzDenied           SecurityEventType = "AUTHZ_DENIED"
 	EventAuthzElevation        SecurityEventType = "AUTHZ_ELEVATION"
 	EventAuthzRoleChange       SecurityEventType = "AUTHZ_ROLE_CHANGE"
+	EventAuthzGeoDenied        SecurityEventType = "AUTHZ_GEO_DENIED"
+	EventAuthzGeoFlagged       SecurityEventType = "AUTHZ_GEO_FLAGGED"
+	EventAuthzCORSRejected     SecurityEventType = "AUTHZ_CORS_REJECTED"
 
 	// Data events
 	EventDataAccess            SecurityEventType = "DATA_ACCESS"
@@ -61,6 +66,8 @@ const (
 	EventAttackXXE             SecurityEventType = "ATTACK_XXE"
 	EventAttackSSRF            SecurityEventType = "ATTACK_SSRF"
 	EventAttackRateLimited     SecurityEventType = "ATTACK_RATE_LIMITED"
+	EventAttackHoneypot        SecurityEventType = "ATTACK_HONEYPOT"
+	EventAttackReplay          SecurityEventType = "ATTACK_REPLAY"
 
 	// Session events
 	EventSessionCreated        SecurityEventType = "SESSION_CREATED"
@@ -79,8 +86,32 @@ const (
 	EventAPIKeyRevoked         SecurityEventType = "API_KEY_REVOKED"
 	EventAPIKeyUsed            SecurityEventType = "API_KEY_USED"
 	EventAPIRateLimited        SecurityEventType = "API_RATE_LIMITED"
+
+	// Alert events
+	EventAlertRecovered        SecurityEventType = "ALERT_RECOVERED"
 )
 
+// IsValid checks if the event type is known
+func (t SecurityEventType) IsValid() bool {
+	switch t {
+	case EventAuthSuccess, EventAuthFailure, EventAuthBruteForce, EventAuthTokenExpired, EventAuthTokenInvalid,
+		EventAuthTokenRefresh, EventAuthLogout, EventAuthPasswordChange, EventAuthPasswordReset,
+		EventAuth2FAEnabled, EventAuth2FADisabled, EventAuth2FAFailure,
+		EventAuthzDenied, EventAuthzElevation, EventAuthzRoleChange, EventAuthzGeoDenied, EventAuthzGeoFlagged,
+		EventAuthzCORSRejected,
+		EventDataAccess, EventDataModification, EventDataDeletion, EventDataExport, EventDataEncryption, EventDataDecryption,
+		EventAttackSQLInjection, EventAttackXSS, EventAttackCSRF, EventAttackPathTraversal, EventAttackCommandInjection,
+		EventAttackNoSQLInjection, EventAttackXXE, EventAttackSSRF, EventAttackRateLimited, EventAttackHoneypot,
+		EventAttackReplay,
+		EventSessionCreated, EventSessionDestroyed, EventSessionHijack, EventSessionFixation,
+		EventSystemConfigChange, EventSystemKeyRotation, EventSystemCertExpiry, EventSystemError,
+		EventAPIKeyCreated, EventAPIKeyRevoked, EventAPIKeyUsed, EventAPIRateLimited,
+		EventAlertRecovered:
+		return true
+	}
+	return false
+}
+
 // SecurityEventSeverity defines severity levels for security events
 type SecurityEventSeverity string
 
@@ -117,10 +148,20 @@ type SecurityAuditor struct {
 	logger       zerolog.Logger
 	redisClient  *redis.Client
 	alertHandlers []AlertHandler
+	alertChannels map[string]AlertHandler // Named handlers alert rules can select
+	alertRules   []AlertRule             // Replace the static thresholds once loaded
+	rulesLoaded  bool
 	mu           sync.RWMutex
 	eventBuffer  chan *SecurityEvent
 	config       AuditConfig
 	metrics      *AuditMetrics
+	budget       StorageBudget
+}
+
+// StorageBudget tells whether the keys under a prefix are over their memory budget,
+// satisfied by cache.MemoryGovernor
+type StorageBudget interface {
+	OverBudget(prefix string) bool
 }
 
 // AuditConfig holds configuration for the security auditor
@@ -143,6 +184,9 @@ type AuditConfig struct {
 	LogToRedis bool
 	// Include stack trace for errors
 	IncludeStackTrace bool
+	// Share of the events below WARNING stored in Redis while the audit keys are over
+	// their memory budget; the others are only counted
+	OverBudgetSampleRate float64
 }
 
 // AlertThresholds defines thresholds for automatic alerting
@@ -185,6 +229,7 @@ func DefaultAuditConfig() AuditConfig {
 		LogToStdout:       true,
 		LogToRedis:        true,
 		IncludeStackTrace: true,
+		OverBudgetSampleRate: 0.1,
 		AlertThresholds: AlertThresholds{
 			FailedAuthAttempts: 5,
 			FailedAuthWindow:   5 * time.Minute,
@@ -203,6 +248,7 @@ func NewSecurityAuditor(config AuditConfig, redisClient *redis.Client) *Security
 		redisClient: redisClient,
 		config:      config,
 		eventBuffer: make(chan *SecurityEvent, config.BufferSize),
+		alertChannels: make(map[string]AlertHandler),
 		metrics: &AuditMetrics{
 			EventsByType:     make(map[SecurityEventType]int64),
 			EventsBySeverity: make(map[SecurityEventSeverity]int64),
@@ -217,6 +263,14 @@ func NewSecurityAuditor(config AuditConfig, redisClient *redis.Client) *Security
 	return auditor
 }
 
+// SetStorageBudget makes the auditor sample the events it stores in Redis while the
+// audit keys are over their memory budget
+func (a *SecurityAuditor) SetStorageBudget(budget StorageBudget) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.budget = budget
+}
+
 // AddAlertHandler adds an alert handler
 func (a *SecurityAuditor) AddAlertHandler(handler AlertHandler) {
 	a.mu.Lock()
@@ -304,7 +358,7 @@ func (a *SecurityAuditor) logToStdout(event *SecurityEvent) {
 		logEvent = logEvent.With().Str("user_id", event.UserID).Logger()
 	}
 	if event.IPAddress != "" {
-		logEvent = logEvent.With().Str("ip_address", event.IPAddress).Logger()
+		logEvent = logEvent.With().Str("ip_address", privacy.LogIP(event.IPAddress)).Logger()
 	}
 	if event.RequestID != "" {
 		logEvent = logEvent.With().Str("request_id", event.RequestID).Logger()
@@ -346,9 +400,15 @@ func (a *SecurityAuditor) logToRedis(ctx context.Context, event *SecurityEvent)
 		return
 	}
 
+	if a.sampledOut(event) {
+		a.countSampledOut(ctx, event)
+		return
+	}
+
 	// Store in sorted set by timestamp
 	key := a.config.RedisKeyPrefix + "events"
 	score := float64(event.Timestamp.UnixNano())
+	indexKeys := []string{key}
 
 	pipe := a.redisClient.Pipeline()
 
@@ -358,17 +418,27 @@ func (a *SecurityAuditor) logToRedis(ctx context.Context, event *SecurityEvent)
 	// Add to type-specific list
 	typeKey := a.config.RedisKeyPrefix + "events:" + string(event.Type)
 	pipe.ZAdd(ctx, typeKey, redis.Z{Score: score, Member: event.ID})
+	indexKeys = append(indexKeys, typeKey)
 
 	// Add to user-specific list if user ID is present
 	if event.UserID != "" {
 		userKey := a.config.RedisKeyPrefix + "user:" + event.UserID
 		pipe.ZAdd(ctx, userKey, redis.Z{Score: score, Member: event.ID})
+		indexKeys = append(indexKeys, userKey)
 	}
 
 	// Add to IP-specific list
 	if event.IPAddress != "" {
 		ipKey := a.config.RedisKeyPrefix + "ip:" + event.IPAddress
 		pipe.ZAdd(ctx, ipKey, redis.Z{Score: score, Member: event.ID})
+		indexKeys = append(indexKeys, ipKey)
+	}
+
+	// Drop the entries past the retention period and let idle indexes expire
+	cutoff := fmt.Sprintf("(%d", time.Now().Add(-a.config.RetentionPeriod).UnixNano())
+	for _, indexKey := range indexKeys {
+		pipe.ZRemRangeByScore(ctx, indexKey, "-inf", cutoff)
+		pipe.Expire(ctx, indexKey, a.config.RetentionPeriod)
 	}
 
 	// Set expiration for the event data
@@ -381,14 +451,68 @@ func (a *SecurityAuditor) logToRedis(ctx context.Context, event *SecurityEvent)
 	}
 }
 
+// sampledOut tells whether an event is left out of Redis because the audit keys are
+// over their memory budget. Events from WARNING up are always stored.
+func (a *SecurityAuditor) sampledOut(event *SecurityEvent) bool {
+	a.mu.RLock()
+	budget := a.budget
+	a.mu.RUnlock()
+
+	if budget == nil || !budget.OverBudget(a.config.RedisKeyPrefix) {
+		return false
+	}
+	switch event.Severity {
+	case SeverityDebug, SeverityInfo:
+		return rand.Float64() >= a.config.OverBudgetSampleRate
+	}
+	return false
+}
+
+// countSampledOut counts the events left out of Redis per type and hour
+func (a *SecurityAuditor) countSampledOut(ctx context.Context, event *SecurityEvent) {
+	key := a.config.RedisKeyPrefix + "sampled_out:" + event.Timestamp.UTC().Format("2006-01-02T15")
+	pipe := a.redisClient.Pipeline()
+	pipe.HIncrBy(ctx, key, string(event.Type), 1)
+	pipe.Expire(ctx, key, a.config.RetentionPeriod)
+	if _, err := pipe.Exec(ctx); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to count sampled out security event")
+	}
+}
+
 // checkAlerts checks if event should trigger alerts
 func (a *SecurityAuditor) checkAlerts(ctx context.Context, event *SecurityEvent) {
-	if len(a.alertHandlers) == 0 {
+	a.mu.RLock()
+	hasHandlers := len(a.alertHandlers) > 0 || len(a.alertChannels) > 0
+	rules, rulesLoaded := a.alertRules, a.rulesLoaded
+	a.mu.RUnlock()
+
+	if !hasHandlers {
 		return
 	}
 
 	var threshold string
 
+	switch event.Type {
+	case EventSessionHijack, EventSessionFixation:
+		// Always alert on session attacks
+		a.triggerAlert(ctx, event, "session_attack")
+		return
+
+	case EventAuthzElevation:
+		// Always alert on privilege escalation
+		a.triggerAlert(ctx, event, "privilege_escalation")
+		return
+
+	case EventAlertRecovered:
+		a.resolveAlert(ctx, event)
+		return
+	}
+
+	if rulesLoaded {
+		a.checkAlertRules(ctx, event, rules)
+		return
+	}
+
 	switch event.Type {
 	case EventAuthFailure, EventAuthBruteForce:
 		threshold = "failed_auth"
@@ -403,21 +527,12 @@ func (a *SecurityAuditor) checkAlerts(ctx context.Context, event *SecurityEvent)
 		}
 
 	case EventAttackSQLInjection, EventAttackXSS, EventAttackCSRF, EventAttackPathTraversal,
-		EventAttackCommandInjection, EventAttackNoSQLInjection, EventAttackXXE, EventAttackSSRF:
+		EventAttackCommandInjection, EventAttackNoSQLInjection, EventAttackXXE, EventAttackSSRF, EventAttackHoneypot,
+		EventAttackReplay:
 		threshold = "attack"
 		if a.shouldAlert(ctx, event.IPAddress, "attacks", a.config.AlertThresholds.AttackEvents, a.config.AlertThresholds.AttackWindow) {
 			a.triggerAlert(ctx, event, threshold)
 		}
-
-	case EventSessionHijack, EventSessionFixation:
-		// Always alert on session attacks
-		threshold = "session_attack"
-		a.triggerAlert(ctx, event, threshold)
-
-	case EventAuthzElevation:
-		// Always alert on privilege escalation
-		threshold = "privilege_escalation"
-		a.triggerAlert(ctx, event, threshold)
 	}
 }
 
@@ -441,10 +556,26 @@ func (a *SecurityAuditor) shouldAlert(ctx context.Context, identifier, category
 	return count >= int64(threshold)
 }
 
-// triggerAlert triggers an alert through all handlers
+// triggerAlert triggers an alert through all handlers and channels
 func (a *SecurityAuditor) triggerAlert(ctx context.Context, event *SecurityEvent, threshold string) {
+	a.triggerAlertOn(ctx, event, threshold, nil)
+}
+
+// triggerAlertOn triggers an alert through the handlers and the given channels, or
+// every channel when none are given
+func (a *SecurityAuditor) triggerAlertOn(ctx context.Context, event *SecurityEvent, threshold string, channels []string) {
 	a.mu.RLock()
-	handlers := a.alertHandlers
+	handlers := append([]AlertHandler(nil), a.alertHandlers...)
+	if len(channels) == 0 {
+		for _, handler := range a.alertChannels {
+			handlers = append(handlers, handler)
+		}
+	}
+	for _, channel := range channels {
+		if handler, ok := a.alertChannels[channel]; ok {
+			handlers = append(handlers, handler)
+		}
+	}
 	a.mu.RUnlock()
 
 	for _, handler := range handlers {
@@ -692,7 +823,7 @@ func (h *SlackAlertHandler) HandleAlert(ctx context.Context, event *SecurityEven
 	if !h.rateLimiter.shouldAlert(alertKey) {
 		log.Debug().
 			Str("event_type", string(event.Type)).
-			Str("ip", event.IPAddress).
+			Str("ip", privacy.LogIP(event.IPAddress)).
 			Msg("Slack alert rate limited")
 		return nil
 	}
@@ -726,7 +857,7 @@ func (h *SlackAlertHandler) HandleAlert(ctx context.Context, event *SecurityEven
 	log.Info().
 		Str("event_type", string(event.Type)).
 		Str("threshold", threshold).
-		Str("ip", event.IPAddress).
+		Str("ip", privacy.LogIP(event.IPAddress)).
 		Msg("Slack security alert sent")
 
 	return nil
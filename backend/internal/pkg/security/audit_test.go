@@ -0,0 +1,33 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBudget struct{ over bool }
+
+func (b fakeBudget) OverBudget(prefix string) bool { return b.over }
+
+func TestSampledOut(t *testing.T) {
+	config := DefaultAuditConfig()
+	config.Workers = 0
+	config.OverBudgetSampleRate = 0
+	auditor := NewSecurityAuditor(config, nil)
+
+	info := &SecurityEvent{Type: EventAPIKeyUsed, Severity: SeverityInfo}
+	critical := &SecurityEvent{Type: EventAPIKeyRevoked, Severity: SeverityCritical}
+
+	assert.False(t, auditor.sampledOut(info), "no budget")
+
+	auditor.SetStorageBudget(fakeBudget{over: false})
+	assert.False(t, auditor.sampledOut(info))
+
+	auditor.SetStorageBudget(fakeBudget{over: true})
+	assert.True(t, auditor.sampledOut(info))
+	assert.False(t, auditor.sampledOut(critical), "severe events always stored")
+
+	auditor.config.OverBudgetSampleRate = 1
+	assert.False(t, auditor.sampledOut(info))
+}
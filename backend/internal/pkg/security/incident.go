@@ -0,0 +1,468 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// IncidentProvider is the incident management service an alert opens an incident in
+type IncidentProvider string
+
+const (
+	IncidentProviderPagerDuty IncidentProvider = "pagerduty"
+	IncidentProviderOpsgenie  IncidentProvider = "opsgenie"
+)
+
+const (
+	defaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	defaultOpsgenieURL  = "https://api.opsgenie.com"
+
+	// recoveredAlertDetail is the detail of a recovered event naming the alert category
+	recoveredAlertDetail = "alert"
+)
+
+// AlertResolver is implemented by alert handlers able to close what they opened when
+// an alert recovers
+type AlertResolver interface {
+	ResolveAlert(ctx context.Context, event *SecurityEvent, threshold string) error
+}
+
+// NewRecoveredEvent creates the event resolving the alerts of a category raised for the
+// IP address, user and festival of the original event
+func NewRecoveredEvent(threshold string, original *SecurityEvent) *SecurityEvent {
+	return &SecurityEvent{
+		Type:       EventAlertRecovered,
+		Severity:   SeverityInfo,
+		UserID:     original.UserID,
+		IPAddress:  original.IPAddress,
+		FestivalID: original.FestivalID,
+		TenantID:   original.TenantID,
+		Details:    map[string]interface{}{recoveredAlertDetail: threshold},
+	}
+}
+
+// resolveAlert passes a recovered event to the handlers able to resolve alerts
+func (a *SecurityAuditor) resolveAlert(ctx context.Context, event *SecurityEvent) {
+	threshold, _ := event.Details[recoveredAlertDetail].(string)
+	if threshold == "" {
+		a.logger.Warn().Msg("Recovered security event does not name its alert")
+		return
+	}
+
+	a.mu.RLock()
+	var resolvers []AlertResolver
+	for _, handler := range a.alertHandlers {
+		if resolver, ok := handler.(AlertResolver); ok {
+			resolvers = append(resolvers, resolver)
+		}
+	}
+	for _, handler := range a.alertChannels {
+		if resolver, ok := handler.(AlertResolver); ok {
+			resolvers = append(resolvers, resolver)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, resolver := range resolvers {
+		go func(r AlertResolver) {
+			if err := r.ResolveAlert(ctx, event, threshold); err != nil {
+				a.logger.Error().Err(err).Str("threshold", threshold).Msg("Failed to resolve alert")
+			}
+		}(resolver)
+	}
+}
+
+// IncidentRoute is where the alerts of a category open incidents
+type IncidentRoute struct {
+	Provider IncidentProvider `yaml:"provider"`
+	// Key is the PagerDuty Events v2 routing key or the Opsgenie API key
+	Key      string `yaml:"key"`
+	Disabled bool   `yaml:"disabled"`
+}
+
+// IncidentRoutes routes alerts by category, the threshold or alert rule name, falling
+// back to the default route
+type IncidentRoutes struct {
+	Default    *IncidentRoute           `yaml:"default"`
+	Categories map[string]IncidentRoute `yaml:"categories"`
+}
+
+// IncidentConfig routes alerts to PagerDuty or Opsgenie. Festival routes take
+// precedence over the platform routes.
+type IncidentConfig struct {
+	IncidentRoutes `yaml:",inline"`
+	Festivals      map[string]IncidentRoutes `yaml:"festivals"` // By festival ID
+	PagerDutyURL   string                    `yaml:"pagerduty_url"`
+	OpsgenieURL    string                    `yaml:"opsgenie_url"` // https://api.eu.opsgenie.com for EU accounts
+}
+
+// LoadIncidentConfig reads the incident routing from a YAML file. Environment
+// variables such as ${PAGERDUTY_ROUTING_KEY} are expanded so keys stay out of the file.
+func LoadIncidentConfig(path string) (*IncidentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incident config: %w", err)
+	}
+
+	var cfg IncidentConfig
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse incident config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks the incident routing
+func (c *IncidentConfig) Validate() error {
+	check := func(scope string, routes IncidentRoutes) error {
+		if routes.Default != nil {
+			if err := routes.Default.validate(); err != nil {
+				return fmt.Errorf("incident route %s default: %w", scope, err)
+			}
+		}
+		for category, route := range routes.Categories {
+			if err := route.validate(); err != nil {
+				return fmt.Errorf("incident route %s %s: %w", scope, category, err)
+			}
+		}
+		return nil
+	}
+
+	if err := check("platform", c.IncidentRoutes); err != nil {
+		return err
+	}
+	for festivalID, routes := range c.Festivals {
+		if err := check("festival "+festivalID, routes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r IncidentRoute) validate() error {
+	if r.Disabled {
+		return nil
+	}
+	switch r.Provider {
+	case IncidentProviderPagerDuty, IncidentProviderOpsgenie:
+		return nil
+	default:
+		return fmt.Errorf("unknown provider %q", r.Provider)
+	}
+}
+
+// Enabled reports whether any route can open incidents
+func (c *IncidentConfig) Enabled() bool {
+	enabled := func(routes IncidentRoutes) bool {
+		if routes.Default != nil && routes.Default.usable() {
+			return true
+		}
+		for _, route := range routes.Categories {
+			if route.usable() {
+				return true
+			}
+		}
+		return false
+	}
+
+	if enabled(c.IncidentRoutes) {
+		return true
+	}
+	for _, routes := range c.Festivals {
+		if enabled(routes) {
+			return true
+		}
+	}
+	return false
+}
+
+// Route returns the route of the alerts of a category raised for a festival, false when
+// they open no incident
+func (c *IncidentConfig) Route(festivalID, threshold string) (IncidentRoute, bool) {
+	route, ok := c.IncidentRoutes.route(threshold)
+	if festival, found := c.Festivals[festivalID]; found && festivalID != "" {
+		if festivalRoute, declared := festival.route(threshold); declared {
+			route, ok = festivalRoute, true
+		}
+	}
+	if !ok || !route.usable() {
+		return IncidentRoute{}, false
+	}
+	return route, true
+}
+
+func (r IncidentRoutes) route(threshold string) (IncidentRoute, bool) {
+	if route, ok := r.Categories[threshold]; ok {
+		return route, true
+	}
+	if r.Default != nil {
+		return *r.Default, true
+	}
+	return IncidentRoute{}, false
+}
+
+// usable reports whether the route opens incidents; a route without a key, typically
+// an unset environment variable, is skipped
+func (r IncidentRoute) usable() bool {
+	return !r.Disabled && r.Key != ""
+}
+
+// IncidentAlertHandler opens PagerDuty or Opsgenie incidents for alerts and resolves
+// them when the alert recovers. Incidents are deduplicated per alert category,
+// festival and IP address or user.
+type IncidentAlertHandler struct {
+	Config IncidentConfig
+	client *http.Client
+}
+
+// NewIncidentAlertHandler creates a new incident alert handler
+func NewIncidentAlertHandler(config IncidentConfig) *IncidentAlertHandler {
+	if config.PagerDutyURL == "" {
+		config.PagerDutyURL = defaultPagerDutyURL
+	}
+	if config.OpsgenieURL == "" {
+		config.OpsgenieURL = defaultOpsgenieURL
+	}
+	return &IncidentAlertHandler{
+		Config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HandleAlert opens an incident, or adds to the open incident of the same alert
+func (h *IncidentAlertHandler) HandleAlert(ctx context.Context, event *SecurityEvent, threshold string) error {
+	route, ok := h.Config.Route(event.FestivalID, threshold)
+	if !ok {
+		return nil
+	}
+
+	dedupKey := IncidentDedupKey(event, threshold)
+	var err error
+	switch route.Provider {
+	case IncidentProviderPagerDuty:
+		err = h.sendPagerDuty(ctx, h.buildPagerDutyEvent(route, event, threshold, dedupKey))
+	case IncidentProviderOpsgenie:
+		err = h.sendOpsgenie(ctx, route, h.Config.OpsgenieURL+"/v2/alerts", h.buildOpsgenieAlert(event, threshold, dedupKey))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("provider", string(route.Provider)).
+		Str("dedup_key", dedupKey).
+		Str("threshold", threshold).
+		Msg("Security incident triggered")
+	return nil
+}
+
+// ResolveAlert resolves the incident opened for the alert
+func (h *IncidentAlertHandler) ResolveAlert(ctx context.Context, event *SecurityEvent, threshold string) error {
+	route, ok := h.Config.Route(event.FestivalID, threshold)
+	if !ok {
+		return nil
+	}
+
+	dedupKey := IncidentDedupKey(event, threshold)
+	var err error
+	switch route.Provider {
+	case IncidentProviderPagerDuty:
+		err = h.sendPagerDuty(ctx, map[string]interface{}{
+			"routing_key":  route.Key,
+			"event_action": "resolve",
+			"dedup_key":    dedupKey,
+		})
+	case IncidentProviderOpsgenie:
+		endpoint := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", h.Config.OpsgenieURL, url.PathEscape(dedupKey))
+		err = h.sendOpsgenie(ctx, route, endpoint, map[string]interface{}{
+			"source": "festivals-security",
+			"note":   "Alert recovered",
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("provider", string(route.Provider)).
+		Str("dedup_key", dedupKey).
+		Str("threshold", threshold).
+		Msg("Security incident resolved")
+	return nil
+}
+
+// IncidentDedupKey identifies the incident of an alert category raised for a festival
+// and an IP address or user, so that repeated alerts add to one incident
+func IncidentDedupKey(event *SecurityEvent, threshold string) string {
+	festival := event.FestivalID
+	if festival == "" {
+		festival = "platform"
+	}
+	subject := event.IPAddress
+	if subject == "" {
+		subject = event.UserID
+	}
+	if subject == "" {
+		subject = "all"
+	}
+	return fmt.Sprintf("festivals-security:%s:%s:%s", threshold, festival, subject)
+}
+
+// pagerDutySeverity maps an event severity to a PagerDuty Events v2 severity
+func pagerDutySeverity(severity SecurityEventSeverity) string {
+	switch severity {
+	case SeverityCritical:
+		return "critical"
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// opsgeniePriority maps an event severity to an Opsgenie priority
+func opsgeniePriority(severity SecurityEventSeverity) string {
+	switch severity {
+	case SeverityCritical:
+		return "P1"
+	case SeverityError:
+		return "P2"
+	case SeverityWarning:
+		return "P3"
+	case SeverityInfo:
+		return "P4"
+	default:
+		return "P5"
+	}
+}
+
+// incidentSummary describes the alert in one line
+func incidentSummary(event *SecurityEvent, threshold string) string {
+	summary := fmt.Sprintf("Security alert %s: %s", threshold, event.Type)
+	if event.IPAddress != "" {
+		summary += " from " + event.IPAddress
+	}
+	if event.FestivalID != "" {
+		summary += " on festival " + event.FestivalID
+	}
+	return summary
+}
+
+// buildPagerDutyEvent creates the PagerDuty Events v2 trigger event
+func (h *IncidentAlertHandler) buildPagerDutyEvent(route IncidentRoute, event *SecurityEvent, threshold, dedupKey string) map[string]interface{} {
+	return map[string]interface{}{
+		"routing_key":  route.Key,
+		"event_action": "trigger",
+		"dedup_key":    dedupKey,
+		"payload": map[string]interface{}{
+			"summary":   incidentSummary(event, threshold),
+			"source":    "festivals-security",
+			"severity":  pagerDutySeverity(event.Severity),
+			"timestamp": event.Timestamp.UTC().Format(time.RFC3339),
+			"component": event.Resource,
+			"group":     event.FestivalID,
+			"class":     string(event.Type),
+			"custom_details": map[string]interface{}{
+				"event_id":   event.ID,
+				"threshold":  threshold,
+				"ip_address": event.IPAddress,
+				"user_id":    event.UserID,
+				"request_id": event.RequestID,
+				"action":     event.Action,
+				"result":     event.Result,
+				"details":    event.Details,
+			},
+		},
+	}
+}
+
+// buildOpsgenieAlert creates the Opsgenie alert
+func (h *IncidentAlertHandler) buildOpsgenieAlert(event *SecurityEvent, threshold, dedupKey string) map[string]interface{} {
+	message := incidentSummary(event, threshold)
+	if len(message) > 130 {
+		message = message[:130]
+	}
+
+	details := map[string]string{
+		"event_id":   event.ID,
+		"event_type": string(event.Type),
+		"threshold":  threshold,
+	}
+	for key, value := range map[string]string{
+		"ip_address":  event.IPAddress,
+		"user_id":     event.UserID,
+		"festival_id": event.FestivalID,
+		"request_id":  event.RequestID,
+		"resource":    event.Resource,
+	} {
+		if value != "" {
+			details[key] = value
+		}
+	}
+
+	return map[string]interface{}{
+		"message":     message,
+		"alias":       dedupKey,
+		"description": fmt.Sprintf("%s (%s) at %s", event.Type, event.Severity, event.Timestamp.UTC().Format(time.RFC3339)),
+		"priority":    opsgeniePriority(event.Severity),
+		"source":      "festivals-security",
+		"entity":      event.FestivalID,
+		"tags":        []string{"security", strings.ToLower(string(event.Type)), threshold},
+		"details":     details,
+	}
+}
+
+// sendPagerDuty sends an event to the PagerDuty Events v2 API
+func (h *IncidentAlertHandler) sendPagerDuty(ctx context.Context, payload map[string]interface{}) error {
+	return h.post(ctx, h.Config.PagerDutyURL, "", payload)
+}
+
+// sendOpsgenie sends a request to the Opsgenie alert API
+func (h *IncidentAlertHandler) sendOpsgenie(ctx context.Context, route IncidentRoute, endpoint string, payload map[string]interface{}) error {
+	return h.post(ctx, endpoint, "GenieKey "+route.Key, payload)
+}
+
+func (h *IncidentAlertHandler) post(ctx context.Context, endpoint, authorization string, payload map[string]interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal incident payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create incident request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send incident request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("incident API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
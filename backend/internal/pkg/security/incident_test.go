@@ -0,0 +1,131 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type incidentRequest struct {
+	Path          string
+	RawQuery      string
+	Authorization string
+	Body          map[string]interface{}
+}
+
+func newIncidentServer(t *testing.T) (*httptest.Server, *[]incidentRequest) {
+	var requests []incidentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := incidentRequest{Path: r.URL.Path, RawQuery: r.URL.RawQuery, Authorization: r.Header.Get("Authorization")}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req.Body))
+		requests = append(requests, req)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestIncidentConfig_Route tests that festival and category routes take precedence
+func TestIncidentConfig_Route(t *testing.T) {
+	config := IncidentConfig{
+		IncidentRoutes: IncidentRoutes{
+			Default: &IncidentRoute{Provider: IncidentProviderPagerDuty, Key: "platform"},
+			Categories: map[string]IncidentRoute{
+				"rate_limit": {Disabled: true},
+			},
+		},
+		Festivals: map[string]IncidentRoutes{
+			"fest-1": {
+				Categories: map[string]IncidentRoute{
+					"attack": {Provider: IncidentProviderOpsgenie, Key: "fest-1"},
+				},
+			},
+			"fest-2": {
+				Default: &IncidentRoute{Provider: IncidentProviderPagerDuty, Key: ""},
+			},
+		},
+	}
+	require.NoError(t, config.Validate())
+	assert.True(t, config.Enabled())
+
+	route, ok := config.Route("", "failed_auth")
+	require.True(t, ok)
+	assert.Equal(t, "platform", route.Key)
+
+	_, ok = config.Route("", "rate_limit")
+	assert.False(t, ok, "disabled category")
+
+	route, ok = config.Route("fest-1", "attack")
+	require.True(t, ok)
+	assert.Equal(t, IncidentProviderOpsgenie, route.Provider)
+
+	route, ok = config.Route("fest-1", "failed_auth")
+	require.True(t, ok)
+	assert.Equal(t, "platform", route.Key, "festival without a route for the category")
+
+	_, ok = config.Route("fest-2", "attack")
+	assert.False(t, ok, "festival route without a key")
+
+	config.Default.Provider = "statuspage"
+	assert.Error(t, config.Validate())
+}
+
+// TestIncidentAlertHandler_PagerDuty tests that a recovered event resolves the incident
+// its alert opened
+func TestIncidentAlertHandler_PagerDuty(t *testing.T) {
+	server, requests := newIncidentServer(t)
+	handler := NewIncidentAlertHandler(IncidentConfig{
+		IncidentRoutes: IncidentRoutes{Default: &IncidentRoute{Provider: IncidentProviderPagerDuty, Key: "routing-key"}},
+		PagerDutyURL:   server.URL,
+	})
+
+	event := &SecurityEvent{
+		Type:       EventAuthBruteForce,
+		Severity:   SeverityCritical,
+		IPAddress:  "10.0.0.1",
+		FestivalID: "fest-1",
+		Timestamp:  time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, handler.HandleAlert(context.Background(), event, "failed_auth"))
+
+	recovered := NewRecoveredEvent("failed_auth", event)
+	require.NoError(t, handler.ResolveAlert(context.Background(), recovered, "failed_auth"))
+
+	require.Len(t, *requests, 2)
+	trigger, resolve := (*requests)[0].Body, (*requests)[1].Body
+	assert.Equal(t, "trigger", trigger["event_action"])
+	assert.Equal(t, "routing-key", trigger["routing_key"])
+	assert.Equal(t, "festivals-security:failed_auth:fest-1:10.0.0.1", trigger["dedup_key"])
+	assert.Equal(t, "critical", trigger["payload"].(map[string]interface{})["severity"])
+	assert.Equal(t, "resolve", resolve["event_action"])
+	assert.Equal(t, trigger["dedup_key"], resolve["dedup_key"])
+}
+
+// TestIncidentAlertHandler_Opsgenie tests that Opsgenie alerts are prioritized and
+// closed by alias
+func TestIncidentAlertHandler_Opsgenie(t *testing.T) {
+	server, requests := newIncidentServer(t)
+	handler := NewIncidentAlertHandler(IncidentConfig{
+		IncidentRoutes: IncidentRoutes{Default: &IncidentRoute{Provider: IncidentProviderOpsgenie, Key: "api-key"}},
+		OpsgenieURL:    server.URL,
+	})
+
+	event := &SecurityEvent{Type: EventAttackSQLInjection, Severity: SeverityWarning, IPAddress: "10.0.0.1"}
+	require.NoError(t, handler.HandleAlert(context.Background(), event, "attack"))
+	require.NoError(t, handler.ResolveAlert(context.Background(), NewRecoveredEvent("attack", event), "attack"))
+
+	require.Len(t, *requests, 2)
+	create, closed := (*requests)[0], (*requests)[1]
+	assert.Equal(t, "/v2/alerts", create.Path)
+	assert.Equal(t, "GenieKey api-key", create.Authorization)
+	assert.Equal(t, "P3", create.Body["priority"])
+	assert.Equal(t, "festivals-security:attack:platform:10.0.0.1", create.Body["alias"])
+	assert.Equal(t, "/v2/alerts/festivals-security:attack:platform:10.0.0.1/close", closed.Path)
+	assert.Equal(t, "identifierType=alias", closed.RawQuery)
+}
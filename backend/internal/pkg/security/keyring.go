@@ -0,0 +1,178 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Keyring holds the HMAC secrets used to sign values such as wallet QR codes and
+// offline transactions. Values are signed with the current secret and verified
+// against every accepted secret, so the secret can be rotated while values signed
+// with the previous one are still in circulation.
+type Keyring struct {
+	mu       sync.RWMutex
+	current  []byte
+	previous []retiredSecret
+	revoked  map[string]bool // Fingerprints not accepted again when listed as previous
+	overlap  time.Duration
+	now      func() time.Time
+}
+
+// retiredSecret is a former secret that is still accepted for verification
+type retiredSecret struct {
+	secret      []byte
+	acceptUntil time.Time
+}
+
+// KeyInfo describes a secret of a keyring without revealing it
+type KeyInfo struct {
+	Fingerprint string     `json:"fingerprint"`
+	Current     bool       `json:"current"`
+	AcceptUntil *time.Time `json:"acceptUntil,omitempty"`
+}
+
+// KeyringChange reports what a rotation changed, by fingerprint
+type KeyringChange struct {
+	Rotated  bool     `json:"rotated"`
+	Current  string   `json:"current"`
+	Replaced string   `json:"replaced,omitempty"`
+	Added    []string `json:"added,omitempty"`
+}
+
+// Changed reports whether the rotation changed the accepted secrets
+func (c KeyringChange) Changed() bool {
+	return c.Rotated || len(c.Added) > 0
+}
+
+// NewKeyring creates a keyring signing with current. The previous secrets are
+// accepted for the overlap window, counted from now.
+func NewKeyring(current string, previous []string, overlap time.Duration) *Keyring {
+	k := &Keyring{
+		current: []byte(current),
+		revoked: make(map[string]bool),
+		overlap: overlap,
+		now:     time.Now,
+	}
+	acceptUntil := k.now().Add(overlap)
+	for _, p := range previous {
+		if p != "" && p != current {
+			k.previous = append(k.previous, retiredSecret{secret: []byte(p), acceptUntil: acceptUntil})
+		}
+	}
+	return k
+}
+
+// Current returns the secret new values are signed with
+func (k *Keyring) Current() []byte {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Accepted returns the secrets values may be signed with, current first
+func (k *Keyring) Accepted() [][]byte {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := k.now()
+	secrets := [][]byte{k.current}
+	for _, p := range k.previous {
+		if now.Before(p.acceptUntil) {
+			secrets = append(secrets, p.secret)
+		}
+	}
+	return secrets
+}
+
+// Verify reports whether signature was produced by sign with one of the accepted secrets
+func (k *Keyring) Verify(signature string, sign func(secret []byte) string) bool {
+	for _, secret := range k.Accepted() {
+		if hmac.Equal([]byte(signature), []byte(sign(secret))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rotate makes current the signing secret. The secret it replaces stays accepted
+// for the overlap window, as do newly listed previous secrets. Secrets retired
+// earlier keep the window they already had, whether listed or not, so reloading
+// the same secrets twice changes nothing; use Revoke to stop accepting one early.
+// A revoked secret is only accepted again if it is made current.
+func (k *Keyring) Rotate(current string, previous []string) KeyringChange {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	change := KeyringChange{Current: Fingerprint([]byte(current))}
+	known := make(map[string]bool)
+	var next []retiredSecret
+	for _, p := range k.previous {
+		if now.Before(p.acceptUntil) && string(p.secret) != current {
+			next = append(next, p)
+			known[string(p.secret)] = true
+		}
+	}
+
+	if string(k.current) != current {
+		change.Rotated = true
+		change.Replaced = Fingerprint(k.current)
+		next = append(next, retiredSecret{secret: k.current, acceptUntil: now.Add(k.overlap)})
+		known[string(k.current)] = true
+	}
+
+	for _, p := range previous {
+		if p == "" || p == current || known[p] || k.revoked[Fingerprint([]byte(p))] {
+			continue
+		}
+		next = append(next, retiredSecret{secret: []byte(p), acceptUntil: now.Add(k.overlap)})
+		known[p] = true
+		change.Added = append(change.Added, Fingerprint([]byte(p)))
+	}
+
+	delete(k.revoked, change.Current)
+	k.current = []byte(current)
+	k.previous = next
+	return change
+}
+
+// Revoke stops accepting the previous secret with the given fingerprint before its
+// overlap window ends. The current secret cannot be revoked, only rotated.
+func (k *Keyring) Revoke(fingerprint string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for i, p := range k.previous {
+		if Fingerprint(p.secret) == fingerprint {
+			k.previous = append(k.previous[:i:i], k.previous[i+1:]...)
+			k.revoked[fingerprint] = true
+			return true
+		}
+	}
+	return false
+}
+
+// Keys describes the accepted secrets, current first
+func (k *Keyring) Keys() []KeyInfo {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := k.now()
+	keys := []KeyInfo{{Fingerprint: Fingerprint(k.current), Current: true}}
+	for _, p := range k.previous {
+		if now.Before(p.acceptUntil) {
+			acceptUntil := p.acceptUntil
+			keys = append(keys, KeyInfo{Fingerprint: Fingerprint(p.secret), AcceptUntil: &acceptUntil})
+		}
+	}
+	return keys
+}
+
+// Fingerprint identifies a secret in logs and audit events without revealing it
+func Fingerprint(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:6])
+}
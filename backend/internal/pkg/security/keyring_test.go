@@ -0,0 +1,85 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKeyring(current string, previous []string, overlap time.Duration) (*Keyring, *time.Time) {
+	now := time.Date(2026, 7, 10, 18, 0, 0, 0, time.UTC)
+	k := NewKeyring(current, previous, overlap)
+	k.now = func() time.Time { return now }
+	for i := range k.previous {
+		k.previous[i].acceptUntil = now.Add(overlap)
+	}
+	return k, &now
+}
+
+func accepted(k *Keyring) []string {
+	var secrets []string
+	for _, s := range k.Accepted() {
+		secrets = append(secrets, string(s))
+	}
+	return secrets
+}
+
+// TestKeyring_RotateKeepsReplacedSecretForOverlap tests that the replaced secret is
+// accepted until the overlap window ends
+func TestKeyring_RotateKeepsReplacedSecretForOverlap(t *testing.T) {
+	k, now := newTestKeyring("a", nil, time.Hour)
+
+	change := k.Rotate("b", nil)
+	assert.True(t, change.Rotated)
+	assert.Equal(t, Fingerprint([]byte("a")), change.Replaced)
+	assert.Equal(t, "b", string(k.Current()))
+	assert.Equal(t, []string{"b", "a"}, accepted(k))
+
+	*now = now.Add(59 * time.Minute)
+	assert.Equal(t, []string{"b", "a"}, accepted(k))
+
+	*now = now.Add(time.Minute)
+	assert.Equal(t, []string{"b"}, accepted(k))
+}
+
+// TestKeyring_RotateIsIdempotent tests that reloading the same secrets keeps the
+// overlap windows instead of extending them
+func TestKeyring_RotateIsIdempotent(t *testing.T) {
+	k, now := newTestKeyring("a", nil, time.Hour)
+	k.Rotate("b", []string{"c"})
+
+	*now = now.Add(30 * time.Minute)
+	change := k.Rotate("b", []string{"c"})
+	assert.False(t, change.Changed())
+
+	*now = now.Add(30 * time.Minute)
+	assert.Equal(t, []string{"b"}, accepted(k))
+}
+
+// TestKeyring_Revoke tests that a revoked secret is not accepted again when it is
+// still listed as previous
+func TestKeyring_Revoke(t *testing.T) {
+	k, _ := newTestKeyring("b", []string{"a"}, time.Hour)
+
+	assert.False(t, k.Revoke(Fingerprint([]byte("b"))), "the current secret cannot be revoked")
+	assert.True(t, k.Revoke(Fingerprint([]byte("a"))))
+	assert.Equal(t, []string{"b"}, accepted(k))
+
+	change := k.Rotate("b", []string{"a"})
+	assert.Empty(t, change.Added)
+	assert.Equal(t, []string{"b"}, accepted(k))
+
+	k.Rotate("a", nil)
+	assert.Equal(t, "a", string(k.Current()))
+}
+
+// TestKeyring_Verify tests verification against the accepted secrets
+func TestKeyring_Verify(t *testing.T) {
+	k, _ := newTestKeyring("b", []string{"a"}, time.Hour)
+	sign := func(secret []byte) string { return "sig:" + string(secret) }
+
+	assert.True(t, k.Verify("sig:b", sign))
+	assert.True(t, k.Verify("sig:a", sign))
+	assert.False(t, k.Verify("sig:c", sign))
+}
@@ -0,0 +1,57 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SessionTokenIssuer is the issuer of the session tokens the API signs itself, for the
+// users signed in without Auth0: through the SSO of their organization, or with a
+// magic link for attendees
+const SessionTokenIssuer = "https://festivals.app/sso"
+
+// Sign-in methods of session tokens
+const (
+	SessionMethodSSO       = "sso"
+	SessionMethodMagicLink = "magic_link"
+)
+
+// SessionClaims are the claims of a session token. They use the names of the Auth0
+// claims so the Auth middleware reads both alike.
+type SessionClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Name          string   `json:"name"`
+	Roles         []string `json:"https://festivals.app/roles"`
+	OrganizerFor  []string `json:"https://festivals.app/organizer_for,omitempty"`
+	AMR           []string `json:"https://festivals.app/amr,omitempty"`
+	MFATime       int64    `json:"https://festivals.app/mfa_time,omitempty"`
+	Method        string   `json:"https://festivals.app/auth_method"`
+	SSOConnection string   `json:"https://festivals.app/sso_connection,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// SessionTokenKey derives the key session tokens are signed with from a keyring
+// secret. Their kid is the Fingerprint of the secret.
+func SessionTokenKey(secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("session-token"))
+	return mac.Sum(nil)
+}
+
+// SignSessionToken signs a session token with the current secret of the keyring, so it
+// is accepted until the secret is retired. The issuer is set to SessionTokenIssuer.
+func SignSessionToken(keyring *Keyring, claims SessionClaims) (string, error) {
+	claims.Issuer = SessionTokenIssuer
+	secret := keyring.Current()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = Fingerprint(secret)
+	signed, err := token.SignedString(SessionTokenKey(secret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign session token: %w", err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,38 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignSessionToken_FollowsRotation tests that a session token is identified by the
+// fingerprint of the secret it was signed with, and verifies with its derived key
+func TestSignSessionToken_FollowsRotation(t *testing.T) {
+	keyring := NewKeyring("secret-1", nil, time.Hour)
+	signed, err := SignSessionToken(keyring, SessionClaims{
+		Roles:            []string{"USER"},
+		Method:           SessionMethodMagicLink,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "email|1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	require.NoError(t, err)
+
+	keyring.Rotate("secret-2", nil)
+	claims := SessionClaims{}
+	_, err = jwt.ParseWithClaims(signed, &claims, func(token *jwt.Token) (interface{}, error) {
+		for _, secret := range keyring.Accepted() {
+			if Fingerprint(secret) == token.Header["kid"] {
+				return SessionTokenKey(secret), nil
+			}
+		}
+		return nil, jwt.ErrTokenUnverifiable
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SessionTokenIssuer, claims.Issuer)
+	assert.Equal(t, "email|1", claims.Subject)
+	assert.Equal(t, SessionMethodMagicLink, claims.Method)
+	assert.NotEqual(t, SessionTokenKey([]byte("secret-1")), SessionTokenKey([]byte("secret-2")))
+}
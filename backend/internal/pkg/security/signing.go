@@ -0,0 +1,54 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Headers of the requests signed by devices. The signature covers the timestamp and
+// nonce, so a captured request cannot be replayed once the nonce is recorded nor
+// after the timestamp is stale.
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp" // Unix seconds
+	SignatureNonceHeader     = "X-Signature-Nonce"
+	SignatureAlgorithm       = "HMAC-SHA256"
+)
+
+// RequestSigningKey derives the key a client signs its requests with from a keyring
+// secret. Keys are never stored: they are derived again to verify, and change when
+// the keyring is rotated.
+func RequestSigningKey(secret []byte, clientID string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("request-signing:" + clientID))
+	return mac.Sum(nil)
+}
+
+// DocumentSigningKey derives the key the financial documents of a festival, such as
+// Z-reports and exports, are signed with from a keyring secret
+func DocumentSigningKey(secret []byte, festivalID string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("document-signing:" + festivalID))
+	return mac.Sum(nil)
+}
+
+// SignRequest returns the hex HMAC-SHA256 of the canonical form of a request: the
+// method, the path with its query, the timestamp, the nonce and the hex SHA-256 of
+// the body, separated by newlines
+func SignRequest(key []byte, method, pathAndQuery string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		strings.ToUpper(method),
+		pathAndQuery,
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
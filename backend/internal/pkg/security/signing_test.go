@@ -0,0 +1,47 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignRequest_CoversRequestAndClient tests that a signature is bound to the
+// client, the request and its nonce
+func TestSignRequest_CoversRequestAndClient(t *testing.T) {
+	secret := []byte("secret")
+	key := RequestSigningKey(secret, "device-1")
+	assert.Equal(t, key, RequestSigningKey(secret, "device-1"))
+	assert.NotEqual(t, key, RequestSigningKey(secret, "device-2"))
+
+	body := []byte(`{"walletId":"w1","amount":450}`)
+	signature := SignRequest(key, "post", "/api/v1/pos-device/payments", 1784102400, "0f8a1c2b3d4e5f60", body)
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, SignRequest(key, "POST", "/api/v1/pos-device/payments", 1784102400, "0f8a1c2b3d4e5f60", body))
+
+	assert.NotEqual(t, signature, SignRequest(key, "POST", "/api/v1/pos-device/payments", 1784102400, "0f8a1c2b3d4e5f61", body))
+	assert.NotEqual(t, signature, SignRequest(key, "POST", "/api/v1/pos-device/payments", 1784102401, "0f8a1c2b3d4e5f60", body))
+	assert.NotEqual(t, signature, SignRequest(key, "POST", "/api/v1/pos-device/payments?x=1", 1784102400, "0f8a1c2b3d4e5f60", body))
+	assert.NotEqual(t, signature, SignRequest(key, "POST", "/api/v1/pos-device/payments", 1784102400, "0f8a1c2b3d4e5f60", []byte(`{"walletId":"w1","amount":4500}`)))
+	assert.NotEqual(t, signature, SignRequest(RequestSigningKey(secret, "device-2"), "POST", "/api/v1/pos-device/payments", 1784102400, "0f8a1c2b3d4e5f60", body))
+}
+
+// TestSignRequest_VerifiedAcrossRotation tests that requests signed with the key of
+// the replaced secret are accepted during the overlap window
+func TestSignRequest_VerifiedAcrossRotation(t *testing.T) {
+	k, now := newTestKeyring("a", nil, time.Hour)
+	oldKey := RequestSigningKey(k.Current(), "device-1")
+	k.Rotate("b", nil)
+
+	signature := SignRequest(oldKey, "DELETE", "/api/v1/pos-device/session", 1784102400, "nonce-0123456789", nil)
+	verify := func() bool {
+		return k.Verify(signature, func(secret []byte) string {
+			return SignRequest(RequestSigningKey(secret, "device-1"), "DELETE", "/api/v1/pos-device/session", 1784102400, "nonce-0123456789", nil)
+		})
+	}
+	assert.True(t, verify())
+
+	*now = now.Add(61 * time.Minute)
+	assert.False(t, verify())
+}
@@ -0,0 +1,147 @@
+// Package tz resolves festival timezones and computes calendar boundaries in
+// festival-local time, so day-based aggregations and schedules do not depend on
+// the server's timezone.
+package tz
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// Embed the timezone database so locations resolve in minimal containers
+	_ "time/tzdata"
+)
+
+// Default is the timezone of a festival that does not set one
+const Default = "Europe/Brussels"
+
+// locations caches loaded locations by name
+var locations sync.Map
+
+// IsValid checks if name is a known IANA timezone, e.g. "Europe/Brussels"
+func IsValid(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := load(name)
+	return err == nil
+}
+
+// Load returns the location of a timezone name, falling back to Default when the
+// name is empty or unknown
+func Load(name string) *time.Location {
+	if name != "" {
+		if loc, err := load(name); err == nil {
+			return loc
+		}
+	}
+	loc, err := load(Default)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func load(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// StartOfDay returns local midnight of the day containing t in loc
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// DayBounds returns the start and exclusive end of the local day containing t in
+// loc; the day lasts 23 or 25 hours when a DST transition falls on it
+func DayBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	start := StartOfDay(t, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Date returns local midnight in loc of the calendar date of t, ignoring the
+// location of t; use it for dates parsed from "2006-01-02" query parameters
+func Date(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// DefaultDayStart is the local time the operational day of a festival starts at when it
+// does not set one; sales made after midnight and before it belong to the previous day
+const DefaultDayStart = "06:00"
+
+// ParseDayStart parses an "HH:MM" day start into the time elapsed since midnight
+func ParseDayStart(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("day start must be formatted as HH:MM: %w", err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsValidDayStart checks if s is an "HH:MM" day start, e.g. "10:00"
+func IsValidDayStart(s string) bool {
+	_, err := ParseDayStart(s)
+	return err == nil
+}
+
+// Calendar splits time into the operational days of a festival, which start at a
+// local time of day rather than at midnight, e.g. from 10:00 to 10:00 the next day
+type Calendar struct {
+	Location *time.Location
+	DayStart time.Duration // Since local midnight, under 24 hours
+}
+
+// NewCalendar returns the calendar of a festival, falling back to Default and
+// DefaultDayStart when the timezone or day start is empty or invalid
+func NewCalendar(timezone, dayStart string) Calendar {
+	start, err := ParseDayStart(dayStart)
+	if err != nil {
+		start, _ = ParseDayStart(DefaultDayStart)
+	}
+	return Calendar{Location: Load(timezone), DayStart: start}
+}
+
+// Bounds returns the start (inclusive) and end (exclusive) of the operational day of
+// the calendar date of date, ignoring the location of date
+func (c Calendar) Bounds(date time.Time) (time.Time, time.Time) {
+	h, m := int(c.DayStart/time.Hour), int(c.DayStart%time.Hour/time.Minute)
+	start := time.Date(date.Year(), date.Month(), date.Day(), h, m, 0, 0, c.Location)
+	end := time.Date(date.Year(), date.Month(), date.Day()+1, h, m, 0, 0, c.Location)
+	return start, end
+}
+
+// DateOf returns the operational day t belongs to, as local midnight of its date
+func (c Calendar) DateOf(t time.Time) time.Time {
+	date := StartOfDay(t, c.Location)
+	if start, _ := c.Bounds(date); t.Before(start) {
+		date = Date(date.AddDate(0, 0, -1), c.Location)
+	}
+	return date
+}
+
+// DayBounds returns the start and exclusive end of the operational day containing t
+func (c Calendar) DayBounds(t time.Time) (time.Time, time.Time) {
+	return c.Bounds(c.DateOf(t))
+}
+
+// StartOfDay returns the start of the operational day containing t
+func (c Calendar) StartOfDay(t time.Time) time.Time {
+	start, _ := c.DayBounds(t)
+	return start
+}
+
+// Offset returns the day start in whole minutes, for SQL that shifts local timestamps
+// back by it before taking their date:
+//
+//	((created_at AT TIME ZONE ?) - make_interval(mins => ?))::date
+func (c Calendar) Offset() int {
+	return int(c.DayStart / time.Minute)
+}
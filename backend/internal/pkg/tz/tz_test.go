@@ -0,0 +1,84 @@
+package tz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIsValid tests timezone name validation
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid("Europe/Brussels"))
+	assert.True(t, IsValid("America/New_York"))
+	assert.True(t, IsValid("UTC"))
+	assert.False(t, IsValid(""))
+	assert.False(t, IsValid("Europe/Atlantis"))
+}
+
+// TestLoad tests loading locations with fallback to the default timezone
+func TestLoad(t *testing.T) {
+	assert.Equal(t, "Asia/Tokyo", Load("Asia/Tokyo").String())
+	assert.Equal(t, Default, Load("").String())
+	assert.Equal(t, Default, Load("Not/AZone").String())
+}
+
+// TestDayBounds tests local day boundaries, including DST transitions
+func TestDayBounds(t *testing.T) {
+	brussels := Load("Europe/Brussels")
+
+	// 23:30 UTC on July 11 is already July 12 in Brussels (UTC+2)
+	start, end := DayBounds(time.Date(2025, time.July, 11, 23, 30, 0, 0, time.UTC), brussels)
+	assert.Equal(t, time.Date(2025, time.July, 11, 22, 0, 0, 0, time.UTC), start.UTC())
+	assert.Equal(t, time.Date(2025, time.July, 12, 22, 0, 0, 0, time.UTC), end.UTC())
+	assert.Equal(t, "2025-07-12T00:00:00+02:00", start.Format(time.RFC3339))
+
+	// Clocks go forward on March 30, 2025: the local day lasts 23 hours
+	start, end = DayBounds(time.Date(2025, time.March, 30, 12, 0, 0, 0, brussels), brussels)
+	assert.Equal(t, 23*time.Hour, end.Sub(start))
+}
+
+// TestDate tests converting a calendar date to local midnight
+func TestDate(t *testing.T) {
+	newYork := Load("America/New_York")
+
+	date := Date(time.Date(2025, time.July, 12, 0, 0, 0, 0, time.UTC), newYork)
+	assert.Equal(t, "2025-07-12T00:00:00-04:00", date.Format(time.RFC3339))
+}
+
+// TestParseDayStart tests parsing HH:MM day starts
+func TestParseDayStart(t *testing.T) {
+	start, err := ParseDayStart("10:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Hour+30*time.Minute, start)
+
+	assert.True(t, IsValidDayStart("00:00"))
+	assert.False(t, IsValidDayStart("24:00"))
+	assert.False(t, IsValidDayStart("10h"))
+}
+
+// TestCalendar tests operational days starting after midnight
+func TestCalendar(t *testing.T) {
+	cal := NewCalendar("Europe/Brussels", "10:00")
+
+	// 03:00 on July 13 still belongs to the operational day of July 12
+	night := time.Date(2025, time.July, 13, 3, 0, 0, 0, cal.Location)
+	assert.Equal(t, "2025-07-12T00:00:00+02:00", cal.DateOf(night).Format(time.RFC3339))
+
+	start, end := cal.DayBounds(night)
+	assert.Equal(t, "2025-07-12T10:00:00+02:00", start.Format(time.RFC3339))
+	assert.Equal(t, "2025-07-13T10:00:00+02:00", end.Format(time.RFC3339))
+
+	// The operational day of October 25 spans the end of DST: 25 hours
+	start, end = cal.Bounds(time.Date(2025, time.October, 25, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 25*time.Hour, end.Sub(start))
+
+	assert.Equal(t, 600, cal.Offset())
+}
+
+// TestNewCalendar_Defaults tests the fallback to the default timezone and day start
+func TestNewCalendar_Defaults(t *testing.T) {
+	cal := NewCalendar("", "")
+	assert.Equal(t, Default, cal.Location.String())
+	assert.Equal(t, 6*time.Hour, cal.DayStart)
+}
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_bulk_price_updates_festival;
+DROP INDEX IF EXISTS idx_bulk_price_updates_status;
+
+-- Drop table
+DROP TABLE IF EXISTS bulk_price_updates;
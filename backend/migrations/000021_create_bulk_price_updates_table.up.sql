@@ -0,0 +1,29 @@
+-- Bulk price updates (scheduled price changes across many products)
+CREATE TABLE IF NOT EXISTS bulk_price_updates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    adjustment_type VARCHAR(20) NOT NULL,
+    adjustment_value BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'SCHEDULED',
+    effective_at TIMESTAMPTZ NOT NULL,
+    revert_at TIMESTAMPTZ,
+    applied_at TIMESTAMPTZ,
+    reverted_at TIMESTAMPTZ,
+    items JSONB NOT NULL DEFAULT '[]',
+    failure_reason TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT chk_bulk_price_updates_revert CHECK (revert_at IS NULL OR revert_at > effective_at)
+);
+
+-- Indexes for bulk price updates
+CREATE INDEX IF NOT EXISTS idx_bulk_price_updates_festival ON bulk_price_updates(festival_id, effective_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bulk_price_updates_status ON bulk_price_updates(status);
+
+COMMENT ON TABLE bulk_price_updates IS 'Scheduled bulk product price changes with automatic rollback';
+COMMENT ON COLUMN bulk_price_updates.adjustment_type IS 'FIXED_AMOUNT, PERCENTAGE or SET_PRICE';
+COMMENT ON COLUMN bulk_price_updates.items IS 'Per-product old and new prices recorded when the update was applied';
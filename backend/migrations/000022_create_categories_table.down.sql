@@ -0,0 +1,13 @@
+-- Drop category links
+DROP INDEX IF EXISTS idx_products_category_id;
+DROP INDEX IF EXISTS idx_stands_category_id;
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+ALTER TABLE stands DROP COLUMN IF EXISTS category_id;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_categories_festival_type;
+DROP INDEX IF EXISTS idx_categories_parent;
+
+-- Drop table
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,34 @@
+-- Festival-level hierarchical categories for stands and products
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES categories(id) ON DELETE RESTRICT,
+    type VARCHAR(20) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    description TEXT,
+    icon VARCHAR(100),
+    sort_order INTEGER DEFAULT 0,
+    tax_class VARCHAR(50),
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT uq_categories_festival_type_slug UNIQUE (festival_id, type, slug),
+    CONSTRAINT chk_categories_type CHECK (type IN ('STAND', 'PRODUCT'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_festival_type ON categories(festival_id, type);
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+
+-- Link stands and products to categories
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_stands_category_id ON stands(category_id);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+
+COMMENT ON TABLE categories IS 'Festival-level category taxonomy for stands and products';
+COMMENT ON COLUMN categories.tax_class IS 'Default tax class inherited by products in this category and its subcategories';
+COMMENT ON COLUMN products.tax_class IS 'Tax class, defaults to the tax class of the product category';
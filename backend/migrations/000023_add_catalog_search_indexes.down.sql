@@ -0,0 +1,11 @@
+-- Drop per-language catalog search indexes
+DROP INDEX IF EXISTS idx_stands_search_simple;
+DROP INDEX IF EXISTS idx_products_search_simple;
+DROP INDEX IF EXISTS idx_stands_search_english;
+DROP INDEX IF EXISTS idx_products_search_english;
+DROP INDEX IF EXISTS idx_stands_search_french;
+DROP INDEX IF EXISTS idx_products_search_french;
+DROP INDEX IF EXISTS idx_stands_search_dutch;
+DROP INDEX IF EXISTS idx_products_search_dutch;
+DROP INDEX IF EXISTS idx_stands_search_german;
+DROP INDEX IF EXISTS idx_products_search_german;
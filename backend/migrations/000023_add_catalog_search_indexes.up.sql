@@ -0,0 +1,65 @@
+-- Per-language full-text indexes for the festival catalog search (stands and products).
+-- The expressions must match standDocument/productDocument in internal/domain/search.
+-- Name trigram indexes for typo tolerance were created in 000014.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_stands_search_simple ON stands USING GIN((
+    setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE(location, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_products_search_simple ON products USING GIN((
+    setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE(category, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_stands_search_english ON stands USING GIN((
+    setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(location, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_products_search_english ON products USING GIN((
+    setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(category, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_stands_search_french ON stands USING GIN((
+    setweight(to_tsvector('french', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('french', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('french', COALESCE(location, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_products_search_french ON products USING GIN((
+    setweight(to_tsvector('french', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('french', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('french', COALESCE(category, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_stands_search_dutch ON stands USING GIN((
+    setweight(to_tsvector('dutch', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('dutch', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('dutch', COALESCE(location, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_products_search_dutch ON products USING GIN((
+    setweight(to_tsvector('dutch', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('dutch', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('dutch', COALESCE(category, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_stands_search_german ON stands USING GIN((
+    setweight(to_tsvector('german', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('german', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('german', COALESCE(location, '')), 'C')
+));
+
+CREATE INDEX IF NOT EXISTS idx_products_search_german ON products USING GIN((
+    setweight(to_tsvector('german', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('german', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('german', COALESCE(category, '')), 'C')
+));
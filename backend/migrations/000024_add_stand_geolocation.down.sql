@@ -0,0 +1,8 @@
+-- Drop stand geolocation
+DROP INDEX IF EXISTS idx_stands_geo_point;
+ALTER TABLE stands DROP COLUMN IF EXISTS geo_point;
+ALTER TABLE stands DROP CONSTRAINT IF EXISTS chk_stands_coordinates;
+ALTER TABLE stands DROP COLUMN IF EXISTS closes_at;
+ALTER TABLE stands DROP COLUMN IF EXISTS opens_at;
+ALTER TABLE stands DROP COLUMN IF EXISTS longitude;
+ALTER TABLE stands DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,25 @@
+-- Stand geolocation and opening hours for "near me" discovery
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS opens_at TIME;
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS closes_at TIME;
+
+ALTER TABLE stands ADD CONSTRAINT chk_stands_coordinates CHECK (
+    (latitude IS NULL AND longitude IS NULL) OR
+    (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+-- Geography point kept in sync with latitude/longitude for distance queries
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS geo_point geography(Point, 4326)
+    GENERATED ALWAYS AS (
+        CASE WHEN latitude IS NOT NULL AND longitude IS NOT NULL
+            THEN ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+        END
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_stands_geo_point ON stands USING GIST(geo_point);
+
+COMMENT ON COLUMN stands.opens_at IS 'Daily opening time in festival local time';
+COMMENT ON COLUMN stands.closes_at IS 'Daily closing time in festival local time; before opens_at when open past midnight';
@@ -0,0 +1,4 @@
+-- Drop order readiness tracking
+DROP INDEX IF EXISTS idx_orders_open;
+DROP INDEX IF EXISTS idx_orders_festival_ready_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS ready_at;
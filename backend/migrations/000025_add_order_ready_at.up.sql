@@ -0,0 +1,8 @@
+-- Track when orders are ready for pickup, used for stand wait-time estimates
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_orders_festival_ready_at ON orders(festival_id, ready_at);
+CREATE INDEX IF NOT EXISTS idx_orders_open ON orders(festival_id, stand_id, created_at)
+    WHERE status = 'PAID' AND ready_at IS NULL;
+
+COMMENT ON COLUMN orders.ready_at IS 'When the stand marked the order ready for pickup';
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_orders_festival_status_created;
+DROP INDEX IF EXISTS idx_staffing_recommendations_festival;
+
+-- Drop table
+DROP TABLE IF EXISTS staffing_recommendations;
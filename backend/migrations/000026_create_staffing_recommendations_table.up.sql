@@ -0,0 +1,26 @@
+-- Staffing recommendations (extra cashiers per stand and hour window, recomputed daily)
+CREATE TABLE IF NOT EXISTS staffing_recommendations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    stand_name VARCHAR(255) NOT NULL,
+    start_hour SMALLINT NOT NULL,
+    end_hour SMALLINT NOT NULL,
+    current_staff INTEGER NOT NULL DEFAULT 0,
+    recommended_staff INTEGER NOT NULL,
+    additional_staff INTEGER NOT NULL,
+    avg_orders_per_hour DOUBLE PRECISION NOT NULL DEFAULT 0,
+    message TEXT NOT NULL,
+    generated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_staffing_recommendations_hours CHECK (start_hour >= 0 AND start_hour < end_hour AND end_hour <= 24)
+);
+
+-- Indexes for staffing recommendations
+CREATE INDEX IF NOT EXISTS idx_staffing_recommendations_festival ON staffing_recommendations(festival_id, stand_name, start_hour);
+
+-- Speeds up the hourly order volume per stand
+CREATE INDEX IF NOT EXISTS idx_orders_festival_status_created ON orders(festival_id, status, created_at);
+
+COMMENT ON TABLE staffing_recommendations IS 'Surge staffing recommendations derived from historical order volume and staffing';
+COMMENT ON COLUMN staffing_recommendations.end_hour IS 'Exclusive end hour in the festival timezone, 24 means midnight';
@@ -0,0 +1,7 @@
+-- Drop table
+DROP TABLE IF EXISTS weather_observations;
+
+-- Drop festival coordinates
+ALTER TABLE festivals DROP CONSTRAINT IF EXISTS chk_festivals_coordinates;
+ALTER TABLE festivals DROP COLUMN IF EXISTS longitude;
+ALTER TABLE festivals DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,31 @@
+-- Festival location coordinates used to fetch the local weather
+ALTER TABLE festivals ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE festivals ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+ALTER TABLE festivals ADD CONSTRAINT chk_festivals_coordinates CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+-- Hourly weather observations at the festival location
+CREATE TABLE IF NOT EXISTS weather_observations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    observed_at TIMESTAMPTZ NOT NULL,
+    temperature_c DOUBLE PRECISION,
+    precipitation_mm DOUBLE PRECISION,
+    wind_speed_kmh DOUBLE PRECISION,
+    cloud_cover_pct DOUBLE PRECISION,
+    weather_code SMALLINT,
+    condition VARCHAR(20) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT uq_weather_observations_hour UNIQUE (festival_id, observed_at)
+);
+
+COMMENT ON TABLE weather_observations IS 'Hourly weather at the festival location, joined into revenue analytics';
+COMMENT ON COLUMN weather_observations.observed_at IS 'Start of the observed hour (UTC)';
+COMMENT ON COLUMN weather_observations.weather_code IS 'WMO weather interpretation code';
+COMMENT ON COLUMN weather_observations.condition IS 'CLEAR, CLOUDY, FOG, RAIN, SNOW or THUNDERSTORM';
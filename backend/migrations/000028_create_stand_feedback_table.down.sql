@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_stand_feedback_user_created;
+DROP INDEX IF EXISTS idx_stand_feedback_stand_status;
+DROP INDEX IF EXISTS idx_stand_feedback_festival_status;
+
+-- Drop table
+DROP TABLE IF EXISTS stand_feedback;
@@ -0,0 +1,28 @@
+-- Attendee feedback on stands (one rating per paid order)
+CREATE TABLE IF NOT EXISTS stand_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'PUBLISHED',
+    flag_reason VARCHAR(100) NOT NULL DEFAULT '',
+    moderated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_stand_feedback_order UNIQUE (order_id),
+    CONSTRAINT chk_stand_feedback_rating CHECK (rating BETWEEN 1 AND 5),
+    CONSTRAINT chk_stand_feedback_status CHECK (status IN ('PUBLISHED', 'PENDING_REVIEW', 'HIDDEN'))
+);
+
+-- Indexes for stand feedback
+CREATE INDEX IF NOT EXISTS idx_stand_feedback_festival_status ON stand_feedback(festival_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stand_feedback_stand_status ON stand_feedback(stand_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stand_feedback_user_created ON stand_feedback(user_id, created_at);
+
+COMMENT ON TABLE stand_feedback IS 'Attendee ratings and comments on stands, screened for abuse before publication';
+COMMENT ON COLUMN stand_feedback.status IS 'PUBLISHED, PENDING_REVIEW (flagged, still counted in ratings) or HIDDEN (excluded)';
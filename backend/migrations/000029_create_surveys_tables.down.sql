@@ -0,0 +1,11 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_survey_answers_submission;
+DROP INDEX IF EXISTS idx_survey_answers_survey_question;
+DROP INDEX IF EXISTS idx_survey_submissions_user_submitted;
+DROP INDEX IF EXISTS idx_survey_submissions_festival_user;
+DROP INDEX IF EXISTS idx_surveys_festival_status;
+
+-- Drop tables
+DROP TABLE IF EXISTS survey_answers;
+DROP TABLE IF EXISTS survey_submissions;
+DROP TABLE IF EXISTS surveys;
@@ -0,0 +1,53 @@
+-- Organizer surveys (questions and target segment stored as JSON)
+CREATE TABLE IF NOT EXISTS surveys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'DRAFT',
+    questions JSONB NOT NULL DEFAULT '[]',
+    segment JSONB NOT NULL DEFAULT '{}',
+    opens_at TIMESTAMPTZ,
+    closes_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    published_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_surveys_status CHECK (status IN ('DRAFT', 'PUBLISHED', 'CLOSED')),
+    CONSTRAINT chk_surveys_schedule CHECK (opens_at IS NULL OR closes_at IS NULL OR closes_at > opens_at)
+);
+
+-- One submission per attendee and survey
+CREATE TABLE IF NOT EXISTS survey_submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    submitted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_survey_submissions_user UNIQUE (survey_id, user_id)
+);
+
+-- Answers of a submission, one row per answered question
+CREATE TABLE IF NOT EXISTS survey_answers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    submission_id UUID NOT NULL REFERENCES survey_submissions(id) ON DELETE CASCADE,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    question_id UUID NOT NULL,
+    rating SMALLINT,
+    choices JSONB,
+    text TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Indexes for surveys
+CREATE INDEX IF NOT EXISTS idx_surveys_festival_status ON surveys(festival_id, status);
+CREATE INDEX IF NOT EXISTS idx_survey_submissions_festival_user ON survey_submissions(festival_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_survey_submissions_user_submitted ON survey_submissions(user_id, submitted_at);
+CREATE INDEX IF NOT EXISTS idx_survey_answers_survey_question ON survey_answers(survey_id, question_id);
+CREATE INDEX IF NOT EXISTS idx_survey_answers_submission ON survey_answers(submission_id);
+
+COMMENT ON TABLE surveys IS 'Post-festival questionnaires built by organizers and targeted to attendee segments';
+COMMENT ON COLUMN surveys.segment IS 'Targeted attendees: ticket types, checked-in only and minimum spend in cents';
+COMMENT ON COLUMN survey_answers.question_id IS 'ID of the question in surveys.questions';
@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_suppressions_created_at;
+DROP INDEX IF EXISTS idx_suppressions_reason;
+DROP INDEX IF EXISTS idx_suppressions_channel_address;
+
+-- Drop table
+DROP TABLE IF EXISTS suppressions;
@@ -0,0 +1,23 @@
+-- Email addresses and phone numbers no message is delivered to
+CREATE TABLE IF NOT EXISTS suppressions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    channel VARCHAR(10) NOT NULL,
+    address VARCHAR(320) NOT NULL,
+    reason VARCHAR(30) NOT NULL,
+    source VARCHAR(30) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    hit_count BIGINT NOT NULL DEFAULT 0,
+    last_hit_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_suppressions_channel CHECK (channel IN ('EMAIL', 'SMS')),
+    CONSTRAINT chk_suppressions_reason CHECK (reason IN ('HARD_BOUNCE', 'SPAM_COMPLAINT', 'UNSUBSCRIBED', 'MANUAL')),
+    CONSTRAINT chk_suppressions_source CHECK (source IN ('PROVIDER_WEBHOOK', 'INBOUND_REPLY', 'DELIVERY_FAILURE', 'ADMIN'))
+);
+
+-- Addresses are stored normalized, one entry per channel
+CREATE UNIQUE INDEX IF NOT EXISTS idx_suppressions_channel_address ON suppressions(channel, address);
+CREATE INDEX IF NOT EXISTS idx_suppressions_reason ON suppressions(channel, reason);
+CREATE INDEX IF NOT EXISTS idx_suppressions_created_at ON suppressions(created_at);
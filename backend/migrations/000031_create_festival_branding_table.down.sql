@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_festival_branding_custom_domain;
+
+-- Drop table
+DROP TABLE IF EXISTS festival_branding;
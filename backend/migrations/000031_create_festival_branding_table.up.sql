@@ -0,0 +1,37 @@
+-- White-label branding of a festival (one row per festival)
+CREATE TABLE IF NOT EXISTS festival_branding (
+    festival_id UUID PRIMARY KEY REFERENCES festivals(id) ON DELETE CASCADE,
+    logo_url TEXT NOT NULL DEFAULT '',
+    logo_media_id UUID,
+    icon_url TEXT NOT NULL DEFAULT '',
+    icon_media_id UUID,
+    primary_color VARCHAR(7) NOT NULL DEFAULT '',
+    secondary_color VARCHAR(7) NOT NULL DEFAULT '',
+    accent_color VARCHAR(7) NOT NULL DEFAULT '',
+    email_sender_name VARCHAR(64) NOT NULL DEFAULT '',
+    email_reply_to VARCHAR(320) NOT NULL DEFAULT '',
+    custom_domain VARCHAR(253),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_festival_branding_primary_color CHECK (primary_color = '' OR primary_color ~ '^#[0-9a-f]{6}$'),
+    CONSTRAINT chk_festival_branding_secondary_color CHECK (secondary_color = '' OR secondary_color ~ '^#[0-9a-f]{6}$'),
+    CONSTRAINT chk_festival_branding_accent_color CHECK (accent_color = '' OR accent_color ~ '^#[0-9a-f]{6}$')
+);
+
+-- A custom domain resolves to a single festival
+CREATE UNIQUE INDEX IF NOT EXISTS idx_festival_branding_custom_domain ON festival_branding(custom_domain) WHERE custom_domain IS NOT NULL;
+
+-- Carry over the logo and colors previously stored in festival settings
+INSERT INTO festival_branding (festival_id, logo_url, primary_color, secondary_color)
+SELECT
+    id,
+    COALESCE(settings->>'logoUrl', ''),
+    CASE WHEN LOWER(settings->>'primaryColor') ~ '^#[0-9a-f]{6}$' THEN LOWER(settings->>'primaryColor') ELSE '' END,
+    CASE WHEN LOWER(settings->>'secondaryColor') ~ '^#[0-9a-f]{6}$' THEN LOWER(settings->>'secondaryColor') ELSE '' END
+FROM festivals
+WHERE COALESCE(settings->>'logoUrl', '') <> ''
+   OR COALESCE(settings->>'primaryColor', '') <> ''
+   OR COALESCE(settings->>'secondaryColor', '') <> ''
+ON CONFLICT (festival_id) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Drop report locale
+ALTER TABLE IF EXISTS reports DROP COLUMN IF EXISTS locale;
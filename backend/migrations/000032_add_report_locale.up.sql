@@ -0,0 +1,2 @@
+-- Language reports are generated in (column headers, titles)
+ALTER TABLE IF EXISTS reports ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'en';
@@ -0,0 +1,13 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS prevent_number_sequences_rewind ON number_sequences;
+DROP TRIGGER IF EXISTS prevent_allocated_numbers_update_delete ON allocated_numbers;
+DROP FUNCTION IF EXISTS prevent_number_sequence_rewind();
+DROP FUNCTION IF EXISTS prevent_allocated_number_changes();
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_allocated_numbers_reference;
+
+-- Drop tables
+DROP TABLE IF EXISTS allocated_numbers;
+DROP TABLE IF EXISTS number_sequences;
+DROP TABLE IF EXISTS numbering_formats;
@@ -0,0 +1,77 @@
+-- Number templates of the document types of a festival (one row per festival and type)
+CREATE TABLE IF NOT EXISTS numbering_formats (
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    document_type VARCHAR(20) NOT NULL,
+    prefix VARCHAR(16) NOT NULL DEFAULT '',
+    template VARCHAR(64) NOT NULL,
+    padding SMALLINT NOT NULL DEFAULT 6,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (festival_id, document_type),
+    CONSTRAINT chk_numbering_formats_document_type CHECK (document_type IN ('INVOICE', 'CREDIT_NOTE', 'RECEIPT', 'SETTLEMENT')),
+    CONSTRAINT chk_numbering_formats_padding CHECK (padding BETWEEN 1 AND 12)
+);
+
+-- Counter of each document type per festival and year; the row is locked by the
+-- allocating transaction, so numbers are handed out one at a time and a rolled
+-- back allocation releases its number
+CREATE TABLE IF NOT EXISTS number_sequences (
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    document_type VARCHAR(20) NOT NULL,
+    year SMALLINT NOT NULL,
+    last_value BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (festival_id, document_type, year),
+    CONSTRAINT chk_number_sequences_last_value CHECK (last_value >= 1)
+);
+
+-- Every number handed out; festivals with allocated numbers cannot be deleted
+CREATE TABLE IF NOT EXISTS allocated_numbers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    document_type VARCHAR(20) NOT NULL,
+    year SMALLINT NOT NULL,
+    sequence BIGINT NOT NULL,
+    number VARCHAR(100) NOT NULL,
+    reference_type VARCHAR(50) NOT NULL DEFAULT '',
+    reference_id VARCHAR(100) NOT NULL DEFAULT '',
+    allocated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    allocated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_allocated_numbers_sequence UNIQUE (festival_id, document_type, year, sequence),
+    CONSTRAINT uq_allocated_numbers_number UNIQUE (festival_id, document_type, number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_allocated_numbers_reference ON allocated_numbers(festival_id, reference_type, reference_id) WHERE reference_id <> '';
+
+-- Allocated numbers and sequence counters are audit records: numbers cannot be
+-- edited or removed and counters can only move forward
+CREATE OR REPLACE FUNCTION prevent_allocated_number_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'allocated numbers are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_allocated_numbers_update_delete
+    BEFORE UPDATE OR DELETE ON allocated_numbers
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_allocated_number_changes();
+
+CREATE OR REPLACE FUNCTION prevent_number_sequence_rewind()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' OR NEW.last_value < OLD.last_value THEN
+        RAISE EXCEPTION 'number sequences cannot be deleted or rewound';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_number_sequences_rewind
+    BEFORE UPDATE OR DELETE ON number_sequences
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_number_sequence_rewind();
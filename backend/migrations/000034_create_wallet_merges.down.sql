@@ -0,0 +1,6 @@
+COMMENT ON COLUMN transactions.type IS 'Transaction type: TOP_UP, CASH_IN, PURCHASE, REFUND, TRANSFER, CASH_OUT';
+
+DROP TABLE IF EXISTS wallet_merges;
+
+DROP INDEX IF EXISTS idx_wallets_merged_into_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS merged_into_id;
@@ -0,0 +1,44 @@
+-- Wallets closed by a merge point to the wallet that absorbed them; their
+-- transactions are shown in that wallet's history
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS merged_into_id UUID REFERENCES wallets(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_wallets_merged_into_id ON wallets(merged_into_id) WHERE merged_into_id IS NOT NULL;
+
+-- Merges of a source wallet into a target wallet of the same festival, kept as the
+-- audit trail of the balance moved and the wristbands relinked
+CREATE TABLE IF NOT EXISTS wallet_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    source_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    target_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    source_user_id UUID NOT NULL,
+    target_user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    amount BIGINT NOT NULL DEFAULT 0,
+    target_balance_before BIGINT NOT NULL DEFAULT 0,
+    target_balance_after BIGINT NOT NULL DEFAULT 0,
+    transaction_count BIGINT NOT NULL DEFAULT 0,
+    tags_relinked BIGINT NOT NULL DEFAULT 0,
+    initiated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    confirmed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    cancelled_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    confirmed_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_wallet_merges_status CHECK (status IN ('PENDING', 'COMPLETED', 'CANCELLED')),
+    CONSTRAINT chk_wallet_merges_distinct CHECK (source_wallet_id <> target_wallet_id)
+);
+
+-- A wallet has at most one pending or completed merge, which makes initiating a
+-- merge idempotent under concurrent requests
+CREATE UNIQUE INDEX IF NOT EXISTS uq_wallet_merges_open_source ON wallet_merges(source_wallet_id) WHERE status IN ('PENDING', 'COMPLETED');
+
+CREATE INDEX IF NOT EXISTS idx_wallet_merges_festival_id ON wallet_merges(festival_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_merges_target_wallet_id ON wallet_merges(target_wallet_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_merges_target_user_id ON wallet_merges(target_user_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_merges_source_user_id ON wallet_merges(source_user_id);
+
+COMMENT ON TABLE wallet_merges IS 'Consolidations of a wallet into another wallet of the same festival';
+COMMENT ON COLUMN transactions.type IS 'Transaction type: TOP_UP, CASH_IN, PURCHASE, REFUND, TRANSFER, CASH_OUT, MERGE';
@@ -0,0 +1,14 @@
+-- Restoring NOT NULL fails while unclaimed wallets hold a balance; refund or
+-- claim them before rolling back
+COMMENT ON COLUMN wallets.claim_code_hash IS NULL;
+COMMENT ON COLUMN wallets.user_id IS NULL;
+
+ALTER TABLE wallet_merges ALTER COLUMN source_user_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_wallets_festival_anonymous;
+DROP INDEX IF EXISTS idx_wallets_claim_code_hash;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS claim_code_hash;
+
+ALTER TABLE wallets ALTER COLUMN user_id SET NOT NULL;
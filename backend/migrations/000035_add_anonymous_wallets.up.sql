@@ -0,0 +1,17 @@
+-- Anonymous wallets are sold at the entrance without a user account and get a
+-- user when claimed with the code printed on the wristband card
+ALTER TABLE wallets ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS claim_code_hash VARCHAR(64);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_claim_code_hash ON wallets(claim_code_hash) WHERE claim_code_hash IS NOT NULL;
+
+-- Unclaimed wallets per festival, for entrance reporting
+CREATE INDEX IF NOT EXISTS idx_wallets_festival_anonymous ON wallets(festival_id) WHERE user_id IS NULL;
+
+-- Merges of anonymous wallets have no source user
+ALTER TABLE wallet_merges ALTER COLUMN source_user_id DROP NOT NULL;
+
+COMMENT ON COLUMN wallets.user_id IS 'Owner of the wallet, NULL for anonymous wallets until claimed';
+COMMENT ON COLUMN wallets.claim_code_hash IS 'HMAC-SHA256 of the claim code printed on the wristband card';
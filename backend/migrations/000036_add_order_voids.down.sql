@@ -0,0 +1,12 @@
+COMMENT ON COLUMN orders.replaced_by_order_id IS NULL;
+COMMENT ON COLUMN orders.replaces_order_id IS NULL;
+COMMENT ON COLUMN orders.void_reason IS NULL;
+COMMENT ON COLUMN orders.status IS 'Order status: PENDING, PAID, CANCELLED, REFUNDED';
+
+DROP INDEX IF EXISTS idx_orders_stand_voided;
+DROP INDEX IF EXISTS idx_orders_replaces_order_id;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS replaced_by_order_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS replaces_order_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS voided_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS void_reason;
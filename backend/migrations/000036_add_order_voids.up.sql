@@ -0,0 +1,17 @@
+-- Orders entered by mistake are voided with a reason code instead of cancelled,
+-- and paid orders rung up wrongly are voided and replaced by a linked correction
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS void_reason VARCHAR(30);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS replaces_order_id UUID REFERENCES orders(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS replaced_by_order_id UUID REFERENCES orders(id) ON DELETE SET NULL;
+
+-- An order can only be corrected once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_replaces_order_id ON orders(replaces_order_id) WHERE replaces_order_id IS NOT NULL;
+
+-- Void monitoring per stand
+CREATE INDEX IF NOT EXISTS idx_orders_stand_voided ON orders(stand_id, voided_at) WHERE status = 'VOIDED';
+
+COMMENT ON COLUMN orders.status IS 'Order status: PENDING, PAID, CANCELLED, REFUNDED, VOIDED';
+COMMENT ON COLUMN orders.void_reason IS 'Reason code given when the order was voided';
+COMMENT ON COLUMN orders.replaces_order_id IS 'Voided order this correction replaces';
+COMMENT ON COLUMN orders.replaced_by_order_id IS 'Correction that replaced this voided order';
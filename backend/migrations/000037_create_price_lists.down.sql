@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_price_list_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS price_list_id;
+
+DROP TABLE IF EXISTS price_lists;
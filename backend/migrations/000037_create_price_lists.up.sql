@@ -0,0 +1,34 @@
+-- Price lists (named prices per stand or festival applied on a schedule)
+CREATE TABLE IF NOT EXISTS price_lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID REFERENCES stands(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    schedule JSONB NOT NULL DEFAULT '{}',
+    prices JSONB NOT NULL DEFAULT '[]',
+    adjustment_type VARCHAR(20),
+    adjustment_value BIGINT NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    activated_at TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Indexes for price lists
+CREATE INDEX IF NOT EXISTS idx_price_lists_festival ON price_lists(festival_id, priority DESC);
+CREATE INDEX IF NOT EXISTS idx_price_lists_stand ON price_lists(stand_id) WHERE stand_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_price_lists_active ON price_lists(festival_id) WHERE active = TRUE;
+
+-- Price list in effect when each order was created, kept for audit after deletion
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS price_list_id UUID;
+CREATE INDEX IF NOT EXISTS idx_orders_price_list_id ON orders(price_list_id) WHERE price_list_id IS NOT NULL;
+
+COMMENT ON TABLE price_lists IS 'Scheduled price lists such as matinee prices or weekend surcharges';
+COMMENT ON COLUMN price_lists.stand_id IS 'Stand the list applies to, NULL for festival-wide lists';
+COMMENT ON COLUMN price_lists.schedule IS 'Validity range, weekdays and daily time window in the festival timezone';
+COMMENT ON COLUMN price_lists.prices IS 'Explicit product prices; other products get the adjustment';
+COMMENT ON COLUMN price_lists.active IS 'Maintained by the activation job from the schedule';
+COMMENT ON COLUMN orders.price_list_id IS 'Price list used to price the order';
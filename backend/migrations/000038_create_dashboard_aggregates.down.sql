@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_orders_festival_created;
+DROP INDEX IF EXISTS idx_transactions_created_wallet;
+
+DROP TABLE IF EXISTS dashboard_aggregate_watermarks;
+DROP TABLE IF EXISTS dashboard_product_aggregates;
+DROP TABLE IF EXISTS dashboard_minute_aggregates;
@@ -0,0 +1,45 @@
+-- Per-minute sales aggregates backing the festival dashboard
+CREATE TABLE IF NOT EXISTS dashboard_minute_aggregates (
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    top_ups BIGINT NOT NULL DEFAULT 0,
+    purchases BIGINT NOT NULL DEFAULT 0,
+    refunds BIGINT NOT NULL DEFAULT 0,
+    volume BIGINT NOT NULL DEFAULT 0,
+    transactions INTEGER NOT NULL DEFAULT 0,
+    purchase_count INTEGER NOT NULL DEFAULT 0,
+    orders INTEGER NOT NULL DEFAULT 0,
+    order_revenue BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (festival_id, bucket, stand_id)
+);
+
+-- Per-hour product sales per stand
+CREATE TABLE IF NOT EXISTS dashboard_product_aggregates (
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    revenue BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (festival_id, bucket, stand_id, product_id)
+);
+
+-- How far the aggregates of each festival have been materialized
+CREATE TABLE IF NOT EXISTS dashboard_aggregate_watermarks (
+    festival_id UUID PRIMARY KEY REFERENCES festivals(id) ON DELETE CASCADE,
+    materialized_until TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Indexes for the incremental refresh, which rescans recent rows per festival
+CREATE INDEX IF NOT EXISTS idx_transactions_created_wallet ON transactions(created_at, wallet_id);
+CREATE INDEX IF NOT EXISTS idx_orders_festival_created ON orders(festival_id, created_at);
+
+COMMENT ON TABLE dashboard_minute_aggregates IS 'Completed transactions and paid orders per stand and minute, maintained by the analytics worker';
+COMMENT ON COLUMN dashboard_minute_aggregates.stand_id IS 'Stand of the transactions, the nil UUID for transactions without a stand such as top-ups';
+COMMENT ON COLUMN dashboard_minute_aggregates.volume IS 'Sum of the absolute amounts of all completed transactions';
+COMMENT ON TABLE dashboard_product_aggregates IS 'Quantity and revenue of paid order items per stand, product and hour';
+COMMENT ON COLUMN dashboard_aggregate_watermarks.materialized_until IS 'Aggregates are complete up to this time, minus the refresh lag';
@@ -0,0 +1,29 @@
+-- Include secret and signing key rotations in the security audit view
+CREATE OR REPLACE VIEW public.security_audit_logs AS
+SELECT
+    id,
+    user_id,
+    action,
+    resource,
+    resource_id,
+    ip,
+    user_agent,
+    metadata,
+    timestamp
+FROM public.audit_logs
+WHERE action IN (
+    'LOGIN',
+    'LOGOUT',
+    'LOGIN_FAILED',
+    'ACCESS_DENIED',
+    'SECURITY_ALERT',
+    'SUSPICIOUS_ACTIVITY',
+    'API_KEY_CREATE',
+    'API_KEY_REVOKE',
+    'ROLE_CHANGE',
+    'USER_BAN',
+    'USER_UNBAN',
+    'KEY_ROTATE',
+    'KEY_REVOKE'
+)
+ORDER BY timestamp DESC;
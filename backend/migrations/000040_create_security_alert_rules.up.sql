@@ -0,0 +1,27 @@
+-- Security alert rules, replacing the static thresholds of the security auditor
+CREATE TABLE IF NOT EXISTS security_alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    threshold INTEGER NOT NULL CHECK (threshold > 0),
+    window_seconds INTEGER NOT NULL CHECK (window_seconds > 0),
+    group_by VARCHAR(20) NOT NULL DEFAULT 'ip' CHECK (group_by IN ('ip', 'user', 'festival', 'none')),
+    channels JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+COMMENT ON TABLE security_alert_rules IS 'Rules deciding when security events alert the security team, hot-loaded by every API instance';
+COMMENT ON COLUMN security_alert_rules.event_types IS 'Security event types counted by the rule';
+COMMENT ON COLUMN security_alert_rules.group_by IS 'What events are counted per: ip, user, festival or none';
+COMMENT ON COLUMN security_alert_rules.channels IS 'Alert channels notified, all channels when empty';
+
+-- Seed the rules matching the previous static thresholds
+INSERT INTO security_alert_rules (name, description, event_types, threshold, window_seconds, group_by) VALUES
+    ('Failed authentication', 'Repeated failed logins from one IP address', '["AUTH_FAILURE", "AUTH_BRUTE_FORCE"]', 5, 300, 'ip'),
+    ('Rate limiting', 'One IP address repeatedly hitting the rate limits', '["ATTACK_RATE_LIMITED", "API_RATE_LIMITED"]', 10, 60, 'ip'),
+    ('Attacks', 'Injection, XSS, CSRF, traversal, XXE and SSRF attempts from one IP address', '["ATTACK_SQL_INJECTION", "ATTACK_XSS", "ATTACK_CSRF", "ATTACK_PATH_TRAVERSAL", "ATTACK_COMMAND_INJECTION", "ATTACK_NOSQL_INJECTION", "ATTACK_XXE", "ATTACK_SSRF"]', 3, 600, 'ip');
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_festival_activities_group;
+DROP INDEX IF EXISTS idx_festival_activities_category;
+DROP INDEX IF EXISTS idx_festival_activities_feed;
+
+DROP TABLE IF EXISTS festival_activities;
@@ -0,0 +1,28 @@
+-- Organizer dashboard activity feed
+CREATE TABLE IF NOT EXISTS festival_activities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('orders', 'refunds', 'products', 'staff', 'alerts')),
+    type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL DEFAULT 'info',
+    title VARCHAR(255) NOT NULL,
+    message TEXT,
+    actor_id UUID,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(100),
+    group_key VARCHAR(255),
+    count INTEGER NOT NULL DEFAULT 1,
+    amount BIGINT NOT NULL DEFAULT 0,
+    metadata JSONB,
+    first_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_festival_activities_feed ON festival_activities(festival_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_festival_activities_category ON festival_activities(festival_id, category, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_festival_activities_group ON festival_activities(festival_id, group_key, first_at DESC)
+    WHERE group_key IS NOT NULL AND group_key <> '';
+
+COMMENT ON TABLE festival_activities IS 'Compact activity feed of the organizer dashboard; repeated activity within 10 minutes is merged into one entry';
+COMMENT ON COLUMN festival_activities.group_key IS 'Entries with the same key started within the compaction window are merged';
+COMMENT ON COLUMN festival_activities.count IS 'Number of occurrences merged into the entry';
@@ -0,0 +1,5 @@
+COMMENT ON COLUMN wallets.qr_generation IS NULL;
+
+DROP INDEX IF EXISTS idx_wallets_qr_revocations;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS qr_generation;
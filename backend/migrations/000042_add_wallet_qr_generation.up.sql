@@ -0,0 +1,8 @@
+-- Wallet QR codes rotate and are signed with a seed derived from the wallet and its
+-- QR generation; bumping the generation revokes the seed and every code signed with it
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS qr_generation INTEGER NOT NULL DEFAULT 0;
+
+-- Revocation list handed to POS devices for offline verification
+CREATE INDEX IF NOT EXISTS idx_wallets_qr_revocations ON wallets(festival_id) WHERE qr_generation > 0 OR status <> 'ACTIVE';
+
+COMMENT ON COLUMN wallets.qr_generation IS 'Generation of the QR material, codes of lower generations are refused';
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_wallet_pass_registrations_push_token;
+DROP INDEX IF EXISTS idx_wallet_pass_registrations_pass;
+
+DROP TABLE IF EXISTS wallet_pass_registrations;
+
+DROP INDEX IF EXISTS idx_wallet_passes_user;
+DROP INDEX IF EXISTS idx_wallet_passes_festival;
+DROP INDEX IF EXISTS idx_wallet_passes_wallet;
+DROP INDEX IF EXISTS idx_wallet_passes_ticket;
+
+DROP TABLE IF EXISTS wallet_passes;
+
+DROP INDEX IF EXISTS idx_wallet_pass_certificates_active;
+
+DROP TABLE IF EXISTS wallet_pass_certificates;
@@ -0,0 +1,61 @@
+-- Apple Wallet pass type certificates and Google Wallet issuer accounts passes are
+-- signed with; one per platform is active
+CREATE TABLE IF NOT EXISTS wallet_pass_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    platform VARCHAR(20) NOT NULL CHECK (platform IN ('apple', 'google')),
+    name VARCHAR(100) NOT NULL,
+    identifier VARCHAR(255) NOT NULL,
+    team_id VARCHAR(50),
+    account VARCHAR(255),
+    certificate_pem TEXT,
+    chain_pem TEXT,
+    private_key TEXT NOT NULL,
+    fingerprint VARCHAR(255),
+    expires_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_pass_certificates_active ON wallet_pass_certificates(platform) WHERE active;
+
+-- Ticket passes and cashless balance cards issued to Apple Wallet and Google Wallet
+CREATE TABLE IF NOT EXISTS wallet_passes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    platform VARCHAR(20) NOT NULL CHECK (platform IN ('apple', 'google')),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('ticket', 'balance')),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    ticket_id UUID REFERENCES tickets(id) ON DELETE CASCADE,
+    wallet_id UUID REFERENCES wallets(id) ON DELETE CASCADE,
+    pass_type_id VARCHAR(255) NOT NULL,
+    auth_token VARCHAR(64) NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_passes_ticket ON wallet_passes(ticket_id, platform) WHERE ticket_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_passes_wallet ON wallet_passes(wallet_id, platform) WHERE wallet_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_wallet_passes_festival ON wallet_passes(festival_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_passes_user ON wallet_passes(user_id);
+
+-- Apple devices registered for push updates of a pass
+CREATE TABLE IF NOT EXISTS wallet_pass_registrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pass_id UUID NOT NULL REFERENCES wallet_passes(id) ON DELETE CASCADE,
+    device_library_id VARCHAR(255) NOT NULL,
+    push_token VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (device_library_id, pass_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_pass_registrations_pass ON wallet_pass_registrations(pass_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_pass_registrations_push_token ON wallet_pass_registrations(push_token);
+
+COMMENT ON TABLE wallet_passes IS 'Wallet passes are rendered from the ticket, wallet and festival on each fetch';
+COMMENT ON COLUMN wallet_passes.pass_type_id IS 'Apple pass type identifier or Google issuer ID the pass was issued with';
+COMMENT ON COLUMN wallet_passes.balance IS 'Balance last pushed to a balance card, in cents';
+COMMENT ON COLUMN wallet_passes.updated_at IS 'Last change pushed to the devices';
+COMMENT ON COLUMN wallet_pass_certificates.private_key IS 'Encrypted PEM private key or Google service account key';
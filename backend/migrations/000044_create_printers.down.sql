@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_print_jobs_order;
+DROP INDEX IF EXISTS idx_print_jobs_festival_status;
+DROP INDEX IF EXISTS idx_print_jobs_festival;
+DROP INDEX IF EXISTS idx_print_jobs_due;
+DROP TABLE IF EXISTS print_jobs;
+
+DROP INDEX IF EXISTS idx_printers_stand;
+DROP INDEX IF EXISTS idx_printers_festival;
+DROP INDEX IF EXISTS idx_printers_token_hash;
+DROP TABLE IF EXISTS printers;
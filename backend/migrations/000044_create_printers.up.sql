@@ -0,0 +1,52 @@
+-- Stand printers and their print jobs, delivered to the print agents
+CREATE TABLE IF NOT EXISTS printers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    connection VARCHAR(20) NOT NULL CHECK (connection IN ('NETWORK', 'USB')),
+    address VARCHAR(255),
+    paper_width INTEGER NOT NULL DEFAULT 80 CHECK (paper_width IN (58, 80)),
+    copies INTEGER NOT NULL DEFAULT 1 CHECK (copies BETWEEN 1 AND 5),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    token_hash VARCHAR(64) NOT NULL,
+    token_prefix VARCHAR(20) NOT NULL,
+    last_seen_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_printers_token_hash ON printers(token_hash);
+CREATE INDEX IF NOT EXISTS idx_printers_festival ON printers(festival_id, stand_id);
+CREATE INDEX IF NOT EXISTS idx_printers_stand ON printers(stand_id) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS print_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    printer_id UUID NOT NULL REFERENCES printers(id) ON DELETE CASCADE,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('ORDER', 'TEST')),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PRINTING', 'PRINTED', 'FAILED')),
+    payload BYTEA NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    lease_expires_at TIMESTAMPTZ,
+    printed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_print_jobs_due ON print_jobs(printer_id, created_at)
+    WHERE status IN ('PENDING', 'PRINTING');
+CREATE INDEX IF NOT EXISTS idx_print_jobs_festival ON print_jobs(festival_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_festival_status ON print_jobs(festival_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_order ON print_jobs(order_id) WHERE order_id IS NOT NULL;
+
+COMMENT ON TABLE printers IS 'Thermal printers of the stands, driven by a print agent authenticated with its own token';
+COMMENT ON COLUMN printers.address IS 'host:port of network printers, device path of USB printers';
+COMMENT ON COLUMN printers.token_hash IS 'SHA-256 of the agent token, which is only shown once';
+COMMENT ON TABLE print_jobs IS 'ESC/POS documents queued for the printers, claimed by the agents with a lease';
+COMMENT ON COLUMN print_jobs.lease_expires_at IS 'Claimed jobs not acknowledged by then are delivered again, or failed when out of attempts';
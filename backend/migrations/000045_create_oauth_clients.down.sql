@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_oauth_client_usage_festival;
+DROP TABLE IF EXISTS oauth_client_usage;
+
+DROP INDEX IF EXISTS idx_oauth_clients_festival;
+DROP INDEX IF EXISTS idx_oauth_clients_client_id;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,42 @@
+-- Machine clients of the public API, authenticated with the OAuth2 client credentials grant
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    client_id VARCHAR(40) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    secret_hash VARCHAR(64) NOT NULL,
+    secret_prefix VARCHAR(20) NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    requests_per_minute INTEGER NOT NULL CHECK (requests_per_minute > 0),
+    requests_per_day INTEGER NOT NULL CHECK (requests_per_day >= requests_per_minute),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'REVOKED')),
+    secret_rotated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    created_by UUID,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_clients_client_id ON oauth_clients(client_id);
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_festival ON oauth_clients(festival_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS oauth_client_usage (
+    client_id UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    hour TIMESTAMPTZ NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    throttled BIGINT NOT NULL DEFAULT 0,
+    tokens_issued BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (client_id, hour)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_client_usage_festival ON oauth_client_usage(festival_id, hour);
+
+COMMENT ON TABLE oauth_clients IS 'Machine clients of a festival, getting scoped access tokens with the client credentials grant';
+COMMENT ON COLUMN oauth_clients.secret_hash IS 'SHA-256 of the client secret, which is only shown once';
+COMMENT ON COLUMN oauth_clients.scopes IS 'RBAC permission strings the client may request';
+COMMENT ON COLUMN oauth_clients.secret_rotated_at IS 'Access tokens issued before are rejected';
+COMMENT ON TABLE oauth_client_usage IS 'Hourly metering of the requests of the clients';
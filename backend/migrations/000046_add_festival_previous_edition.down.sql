@@ -0,0 +1,5 @@
+COMMENT ON COLUMN festivals.previous_edition_id IS NULL;
+
+DROP INDEX IF EXISTS idx_festivals_previous_edition;
+
+ALTER TABLE festivals DROP COLUMN IF EXISTS previous_edition_id;
@@ -0,0 +1,6 @@
+-- Festivals link to their previous edition so sales can be compared across years
+ALTER TABLE festivals ADD COLUMN IF NOT EXISTS previous_edition_id UUID REFERENCES festivals(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_festivals_previous_edition ON festivals(previous_edition_id) WHERE previous_edition_id IS NOT NULL;
+
+COMMENT ON COLUMN festivals.previous_edition_id IS 'Previous edition of the same festival, used by edition comparisons';
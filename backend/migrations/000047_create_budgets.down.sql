@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_budget_entries_line;
+DROP TABLE IF EXISTS budget_entries;
+
+DROP INDEX IF EXISTS idx_budget_lines_festival;
+DROP TABLE IF EXISTS budget_lines;
@@ -0,0 +1,38 @@
+-- Revenue and cost budgets of a festival, one line per category
+CREATE TABLE IF NOT EXISTS budget_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('REVENUE', 'COST')),
+    name VARCHAR(100) NOT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'MANUAL',
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    tolerance_percent INTEGER NOT NULL DEFAULT 10 CHECK (tolerance_percent BETWEEN 0 AND 100),
+    period_start TIMESTAMPTZ,
+    period_end TIMESTAMPTZ,
+    notes TEXT,
+    alerted_status VARCHAR(20) NOT NULL DEFAULT '',
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_lines_festival ON budget_lines(festival_id);
+
+-- Actuals recorded by organizers on MANUAL lines
+CREATE TABLE IF NOT EXISTS budget_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    line_id UUID NOT NULL REFERENCES budget_lines(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    description VARCHAR(255),
+    incurred_at TIMESTAMPTZ NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_entries_line ON budget_entries(line_id, incurred_at);
+
+COMMENT ON TABLE budget_lines IS 'Budgeted revenue and costs per category, tracked against actuals';
+COMMENT ON COLUMN budget_lines.source IS 'Where actuals come from: MANUAL, ORDERS, PRODUCT_CATEGORY, TICKETS, SETTLEMENTS, PAYMENT_FEES or REFUNDS';
+COMMENT ON COLUMN budget_lines.alerted_status IS 'Status organizers were last alerted about, cleared once the line is back on track';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_vendor_payouts_festival;
+DROP INDEX IF EXISTS idx_vendor_payouts_stand;
+DROP TABLE IF EXISTS vendor_payouts;
+
+DROP INDEX IF EXISTS idx_stand_owners_festival;
+DROP INDEX IF EXISTS idx_stand_owners_user;
+DROP TABLE IF EXISTS stand_owners;
+
+ALTER TABLE stands DROP COLUMN IF EXISTS commission_percent;
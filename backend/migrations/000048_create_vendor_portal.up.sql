@@ -0,0 +1,42 @@
+-- Festival's cut of vendor sales, deducted on vendor statements
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS commission_percent NUMERIC(5,2) NOT NULL DEFAULT 0
+    CHECK (commission_percent BETWEEN 0 AND 100);
+
+-- Vendor accounts owning a stand, granting them the vendor portal for it
+CREATE TABLE IF NOT EXISTS stand_owners (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (stand_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stand_owners_user ON stand_owners(user_id);
+CREATE INDEX IF NOT EXISTS idx_stand_owners_festival ON stand_owners(festival_id);
+
+-- Payouts of statement balances to stand owners
+CREATE TABLE IF NOT EXISTS vendor_payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PAID', 'FAILED')),
+    reference VARCHAR(100),
+    notes TEXT,
+    paid_at TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (period_end > period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vendor_payouts_stand ON vendor_payouts(stand_id, period_start);
+CREATE INDEX IF NOT EXISTS idx_vendor_payouts_festival ON vendor_payouts(festival_id);
+
+COMMENT ON TABLE stand_owners IS 'Vendor accounts owning a stand; checked by the vendor portal on every request';
+COMMENT ON TABLE vendor_payouts IS 'Payouts of statement balances to the owners of a stand';
+COMMENT ON COLUMN vendor_payouts.reference IS 'Bank transfer reference shown to the vendor';
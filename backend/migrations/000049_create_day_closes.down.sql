@@ -0,0 +1,23 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS prevent_day_close_adjustments_update_delete ON day_close_adjustments;
+DROP TRIGGER IF EXISTS prevent_day_closes_update_delete ON day_closes;
+DROP FUNCTION IF EXISTS prevent_day_close_changes();
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_day_close_adjustments_close;
+DROP INDEX IF EXISTS idx_shift_handovers_festival_date;
+DROP INDEX IF EXISTS idx_shift_handovers_stand_date;
+DROP INDEX IF EXISTS idx_day_closes_period;
+DROP INDEX IF EXISTS idx_day_closes_festival_date;
+DROP INDEX IF EXISTS idx_day_closes_stand_date;
+
+-- Drop tables
+DROP TABLE IF EXISTS day_close_adjustments;
+DROP TABLE IF EXISTS shift_handovers;
+DROP TABLE IF EXISTS day_closes;
+
+-- Z-report numbers stay allocated; only the formats are restricted again
+DELETE FROM numbering_formats WHERE document_type = 'Z_REPORT';
+ALTER TABLE numbering_formats DROP CONSTRAINT IF EXISTS chk_numbering_formats_document_type;
+ALTER TABLE numbering_formats ADD CONSTRAINT chk_numbering_formats_document_type
+    CHECK (document_type IN ('INVOICE', 'CREDIT_NOTE', 'RECEIPT', 'SETTLEMENT'));
@@ -0,0 +1,91 @@
+-- Z-reports are numbered with the other festival documents
+ALTER TABLE numbering_formats DROP CONSTRAINT IF EXISTS chk_numbering_formats_document_type;
+ALTER TABLE numbering_formats ADD CONSTRAINT chk_numbering_formats_document_type
+    CHECK (document_type IN ('INVOICE', 'CREDIT_NOTE', 'RECEIPT', 'SETTLEMENT', 'Z_REPORT'));
+
+-- End-of-day closes of a business day, per stand or festival-wide (stand_id NULL).
+-- The orders created within the period are frozen once the close exists.
+CREATE TABLE IF NOT EXISTS day_closes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    stand_id UUID REFERENCES stands(id),
+    business_date DATE NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    report_number VARCHAR(100) NOT NULL,
+    report JSONB NOT NULL,
+    net_sales BIGINT NOT NULL,
+    cash_variance BIGINT,
+    card_variance BIGINT,
+    wallet_variance BIGINT NOT NULL DEFAULT 0,
+    notes TEXT,
+    closed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    closed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_day_closes_report_number UNIQUE (festival_id, report_number),
+    CONSTRAINT chk_day_closes_period CHECK (period_end > period_start)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_day_closes_stand_date ON day_closes(stand_id, business_date) WHERE stand_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_day_closes_festival_date ON day_closes(festival_id, business_date) WHERE stand_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_day_closes_period ON day_closes(festival_id, period_start, period_end);
+
+-- Cash handed over at the end of each shift at a stand
+CREATE TABLE IF NOT EXISTS shift_handovers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    business_date DATE NOT NULL,
+    from_staff_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    to_staff_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    handed_over_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    orders BIGINT NOT NULL DEFAULT 0,
+    expected_cash BIGINT NOT NULL,
+    counted_cash BIGINT NOT NULL CHECK (counted_cash >= 0),
+    cash_variance BIGINT NOT NULL,
+    notes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_handovers_stand_date ON shift_handovers(stand_id, business_date, handed_over_at);
+CREATE INDEX IF NOT EXISTS idx_shift_handovers_festival_date ON shift_handovers(festival_id, business_date);
+
+-- Audited changes to closed business days
+CREATE TABLE IF NOT EXISTS day_close_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    close_id UUID NOT NULL REFERENCES day_closes(id),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('ORDER_REFUND', 'ORDER_CANCEL', 'MANUAL')),
+    order_id UUID REFERENCES orders(id),
+    payment_method VARCHAR(20),
+    amount BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_day_close_adjustments_close ON day_close_adjustments(close_id, created_at);
+
+-- Closes and their adjustments are accounting records: they cannot be edited or removed
+CREATE OR REPLACE FUNCTION prevent_day_close_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_day_closes_update_delete
+    BEFORE UPDATE OR DELETE ON day_closes
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_day_close_changes();
+
+CREATE TRIGGER prevent_day_close_adjustments_update_delete
+    BEFORE UPDATE OR DELETE ON day_close_adjustments
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_day_close_changes();
+
+COMMENT ON TABLE day_closes IS 'End-of-day closes; orders of a closed period can only change through day_close_adjustments';
+COMMENT ON COLUMN day_closes.business_date IS 'Festival-local business day, running from 06:00 to 06:00 the next day';
+COMMENT ON COLUMN day_closes.report IS 'Z-report frozen at closing time';
+COMMENT ON TABLE shift_handovers IS 'Cash counted at shift changes against the cash taken since the previous handover';
+COMMENT ON TABLE day_close_adjustments IS 'Audited changes to closed business days';
@@ -0,0 +1,13 @@
+-- Drop table and pitch delivery
+DROP INDEX IF EXISTS idx_orders_delivery_location;
+DROP INDEX IF EXISTS idx_orders_delivery_queue;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivered_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS assigned_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_due_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS runner_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_status;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_location_label;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_location_id;
+
+DROP INDEX IF EXISTS idx_delivery_locations_festival;
+DROP TABLE IF EXISTS delivery_locations;
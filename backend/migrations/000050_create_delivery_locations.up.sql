@@ -0,0 +1,40 @@
+-- Tables and camping pitches orders can be delivered to by runners
+CREATE TABLE IF NOT EXISTS delivery_locations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID REFERENCES stands(id) ON DELETE SET NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('TABLE', 'PITCH')),
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(100),
+    zone VARCHAR(100),
+    sla_minutes INTEGER NOT NULL DEFAULT 20 CHECK (sla_minutes > 0),
+    qr_version INTEGER NOT NULL DEFAULT 1,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (festival_id, code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_locations_festival ON delivery_locations(festival_id, kind, code);
+
+-- Delivery of orders to a location
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_location_id UUID REFERENCES delivery_locations(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_location_label VARCHAR(150);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_status VARCHAR(20)
+    CHECK (delivery_status IN ('WAITING', 'ASSIGNED', 'DELIVERED'));
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS runner_id UUID;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_due_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_queue ON orders(festival_id, delivery_due_at)
+    WHERE status = 'PAID' AND delivery_status IN ('WAITING', 'ASSIGNED');
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_location ON orders(delivery_location_id, created_at)
+    WHERE delivery_location_id IS NOT NULL;
+
+COMMENT ON TABLE delivery_locations IS 'Numbered tables and camping pitches, each with a signed QR code to order from';
+COMMENT ON COLUMN delivery_locations.stand_id IS 'Only stand delivering to the location, any stand when NULL';
+COMMENT ON COLUMN delivery_locations.sla_minutes IS 'Time allowed from order to delivery';
+COMMENT ON COLUMN delivery_locations.qr_version IS 'Bumped to revoke the printed QR codes of the location';
+COMMENT ON COLUMN orders.delivery_location_label IS 'Label of the delivery location when the order was placed';
+COMMENT ON COLUMN orders.delivery_due_at IS 'Delivery deadline set by the SLA of the location';
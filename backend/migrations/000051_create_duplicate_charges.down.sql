@@ -0,0 +1,5 @@
+-- Drop duplicate charge detection
+DROP INDEX IF EXISTS idx_transactions_purchases_recent;
+DROP INDEX IF EXISTS idx_duplicate_charges_flagged;
+DROP INDEX IF EXISTS idx_duplicate_charges_festival;
+DROP TABLE IF EXISTS duplicate_charges;
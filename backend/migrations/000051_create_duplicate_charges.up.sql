@@ -0,0 +1,34 @@
+-- Wallet purchases detected as likely duplicates of an earlier purchase (same wallet,
+-- stand and amount within seconds), reversed automatically or after review depending
+-- on the duplicateChargePolicy festival setting
+CREATE TABLE IF NOT EXISTS duplicate_charges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    original_transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    duplicate_transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    gap_ms BIGINT NOT NULL DEFAULT 0,
+    charged_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'FLAGGED',
+    auto_reversed BOOLEAN NOT NULL DEFAULT FALSE,
+    reversal_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_duplicate_charges_transaction UNIQUE (duplicate_transaction_id),
+    CONSTRAINT chk_duplicate_charges_status CHECK (status IN ('FLAGGED', 'REVERSED', 'DISMISSED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_duplicate_charges_festival ON duplicate_charges(festival_id, charged_at DESC);
+CREATE INDEX IF NOT EXISTS idx_duplicate_charges_flagged ON duplicate_charges(festival_id) WHERE status = 'FLAGGED';
+
+-- Scans compare the recent purchases of each wallet
+CREATE INDEX IF NOT EXISTS idx_transactions_purchases_recent ON transactions(wallet_id, stand_id, created_at) WHERE type = 'PURCHASE';
+
+COMMENT ON TABLE duplicate_charges IS 'Wallet purchases detected as likely duplicates, with their review outcome';
@@ -0,0 +1,4 @@
+-- Drop wallet reconciliation reports
+DROP INDEX IF EXISTS idx_transactions_top_up_reference;
+DROP INDEX IF EXISTS idx_wallet_reconciliations_festival;
+DROP TABLE IF EXISTS wallet_reconciliations;
@@ -0,0 +1,30 @@
+-- Wallet reconciliation reports comparing the wallet liabilities of a festival with its
+-- ledger, Stripe payments and cash top-ups, with the discrepancies itemized
+CREATE TABLE IF NOT EXISTS wallet_reconciliations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    liabilities BIGINT NOT NULL DEFAULT 0,
+    ledger_balance BIGINT NOT NULL DEFAULT 0,
+    stripe_captured BIGINT NOT NULL DEFAULT 0,
+    stripe_credited BIGINT NOT NULL DEFAULT 0,
+    cash_top_ups BIGINT NOT NULL DEFAULT 0,
+    drift BIGINT NOT NULL DEFAULT 0,
+    threshold BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    discrepancies JSONB NOT NULL DEFAULT '[]',
+    discrepancy_count INTEGER NOT NULL DEFAULT 0,
+    stripe_checked INTEGER NOT NULL DEFAULT 0,
+    alerted BOOLEAN NOT NULL DEFAULT FALSE,
+    triggered_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL,
+
+    CONSTRAINT chk_wallet_reconciliations_status CHECK (status IN ('BALANCED', 'DRIFT'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_reconciliations_festival ON wallet_reconciliations(festival_id, started_at DESC);
+
+-- Stripe payments are matched with the top-ups referencing their intent
+CREATE INDEX IF NOT EXISTS idx_transactions_top_up_reference ON transactions(reference) WHERE type = 'TOP_UP';
+
+COMMENT ON TABLE wallet_reconciliations IS 'Nightly and on-demand wallet reconciliation reports';
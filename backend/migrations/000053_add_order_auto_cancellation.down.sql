@@ -0,0 +1,3 @@
+-- Drop order auto-cancellation
+DROP INDEX IF EXISTS idx_orders_pending_created;
+ALTER TABLE orders DROP COLUMN IF EXISTS auto_cancelled_at;
@@ -0,0 +1,8 @@
+-- Pending orders left unpaid past the pendingOrderTtlMinutes festival setting are
+-- cancelled by the worker, which records when
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS auto_cancelled_at TIMESTAMPTZ;
+
+-- The worker scans the pending orders, oldest first
+CREATE INDEX IF NOT EXISTS idx_orders_pending_created ON orders(created_at) WHERE status = 'PENDING';
+
+COMMENT ON COLUMN orders.auto_cancelled_at IS 'When the order was cancelled for not being paid in time';
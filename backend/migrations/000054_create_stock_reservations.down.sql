@@ -0,0 +1,2 @@
+-- Drop stock reservations
+DROP TABLE IF EXISTS stock_reservations;
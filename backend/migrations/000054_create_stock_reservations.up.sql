@@ -0,0 +1,22 @@
+-- Stock held for pending orders between their creation and payment, so limited
+-- products cannot be sold twice. order_id has no foreign key, as the stock is held
+-- just before the order is inserted.
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'HELD',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_stock_reservations_quantity CHECK (quantity > 0),
+    CONSTRAINT chk_stock_reservations_status CHECK (status IN ('HELD', 'CONVERTED', 'RELEASED'))
+);
+
+-- Orders sum the active holds of their products
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_held ON stock_reservations(product_id, expires_at) WHERE status = 'HELD';
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_order ON stock_reservations(order_id);
+
+COMMENT ON TABLE stock_reservations IS 'Stock held for pending orders until paid, cancelled or expired';
@@ -0,0 +1,4 @@
+-- Drop product recalls
+UPDATE products SET status = 'INACTIVE' WHERE status = 'RECALLED';
+COMMENT ON COLUMN products.status IS 'Product status: ACTIVE, INACTIVE, OUT_OF_STOCK';
+DROP TABLE IF EXISTS product_recalls;
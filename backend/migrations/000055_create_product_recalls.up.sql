@@ -0,0 +1,33 @@
+-- Products recalled across every stand of a festival, e.g. when a food batch must be
+-- withdrawn. Recalled products are set to the RECALLED status until the recall is
+-- lifted; the worker then refunds and notifies their purchasers.
+CREATE TABLE IF NOT EXISTS product_recalls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    sku VARCHAR(100),
+    product_name VARCHAR(255) NOT NULL,
+    product_ids JSONB NOT NULL DEFAULT '[]',
+    stand_ids JSONB NOT NULL DEFAULT '[]',
+    reason TEXT NOT NULL,
+    sold_since TIMESTAMPTZ,
+    refund_purchases BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    pending_cancelled INTEGER NOT NULL DEFAULT 0,
+    orders_affected INTEGER NOT NULL DEFAULT 0,
+    orders_refunded INTEGER NOT NULL DEFAULT 0,
+    refunds_failed INTEGER NOT NULL DEFAULT 0,
+    purchasers_notified INTEGER NOT NULL DEFAULT 0,
+    notified_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    lifted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    lifted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_product_recalls_status CHECK (status IN ('ACTIVE', 'LIFTED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_recalls_festival ON product_recalls(festival_id, created_at DESC);
+
+COMMENT ON TABLE product_recalls IS 'Products recalled across the stands of a festival, with the outcome of the purchaser notification';
+COMMENT ON COLUMN products.status IS 'Product status: ACTIVE, INACTIVE, OUT_OF_STOCK, RECALLED';
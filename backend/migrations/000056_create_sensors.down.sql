@@ -0,0 +1,6 @@
+-- Drop sensors
+DROP TABLE IF EXISTS restock_tasks;
+DROP TABLE IF EXISTS sensor_alerts;
+DROP TABLE IF EXISTS sensor_readings;
+DROP TABLE IF EXISTS sensors;
+DROP TABLE IF EXISTS sensor_devices;
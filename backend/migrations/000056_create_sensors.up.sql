@@ -0,0 +1,98 @@
+-- Fridge temperature and keg level sensors of the stands, reporting through a gateway
+-- device authenticated with its API key
+CREATE TABLE IF NOT EXISTS sensor_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL,
+    last_seen_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_sensor_devices_key_hash UNIQUE (key_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sensor_devices_festival ON sensor_devices(festival_id, stand_id);
+
+CREATE TABLE IF NOT EXISTS sensors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    device_id UUID NOT NULL REFERENCES sensor_devices(id) ON DELETE CASCADE,
+    external_id VARCHAR(100) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    min_value DOUBLE PRECISION,
+    max_value DOUBLE PRECISION,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_value DOUBLE PRECISION,
+    last_reading_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_sensors_device_external UNIQUE (device_id, external_id),
+    CONSTRAINT chk_sensors_kind CHECK (kind IN ('TEMPERATURE', 'KEG_LEVEL'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sensors_festival ON sensors(festival_id, stand_id);
+
+CREATE TABLE IF NOT EXISTS sensor_readings (
+    id BIGSERIAL PRIMARY KEY,
+    sensor_id UUID NOT NULL REFERENCES sensors(id) ON DELETE CASCADE,
+    value DOUBLE PRECISION NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- History queries scan the readings of one sensor over a time range
+CREATE INDEX IF NOT EXISTS idx_sensor_readings_sensor_time ON sensor_readings(sensor_id, recorded_at);
+
+CREATE TABLE IF NOT EXISTS sensor_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    sensor_id UUID NOT NULL REFERENCES sensors(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    threshold DOUBLE PRECISION NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    opened_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_sensor_alerts_type CHECK (type IN ('ABOVE_MAX', 'BELOW_MIN')),
+    CONSTRAINT chk_sensor_alerts_status CHECK (status IN ('OPEN', 'RESOLVED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sensor_alerts_festival ON sensor_alerts(festival_id, opened_at DESC);
+-- At most one open alert per sensor
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sensor_alerts_open ON sensor_alerts(sensor_id) WHERE status = 'OPEN';
+
+CREATE TABLE IF NOT EXISTS restock_tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    sensor_id UUID NOT NULL REFERENCES sensors(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    alert_id UUID NOT NULL REFERENCES sensor_alerts(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    level DOUBLE PRECISION NOT NULL,
+    completed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_restock_tasks_status CHECK (status IN ('OPEN', 'DONE'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_restock_tasks_festival ON restock_tasks(festival_id, created_at DESC);
+-- At most one open task per keg
+CREATE UNIQUE INDEX IF NOT EXISTS uq_restock_tasks_open ON restock_tasks(sensor_id) WHERE status = 'OPEN';
+
+COMMENT ON TABLE sensor_devices IS 'Sensor gateways of the stands, authenticated with an API key';
+COMMENT ON TABLE sensor_readings IS 'Fridge temperature (°C) and keg level (%) telemetry';
+COMMENT ON TABLE restock_tasks IS 'Kegs to replace, opened when a keg sensor reads below its minimum';
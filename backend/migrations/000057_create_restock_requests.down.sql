@@ -0,0 +1,4 @@
+-- Drop restock requests
+DROP TABLE IF EXISTS product_stock_movements;
+DROP TABLE IF EXISTS restock_rules;
+DROP TABLE IF EXISTS restock_requests;
@@ -0,0 +1,66 @@
+-- Restock requests of the stands to the central warehouse, with the quantities
+-- requested, picked and received of each product in items
+CREATE TABLE IF NOT EXISTS restock_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    stand_name VARCHAR(255) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'REQUESTED',
+    items JSONB NOT NULL DEFAULT '[]',
+    note TEXT,
+    requested_by UUID,
+    picked_by UUID,
+    received_by UUID,
+    cancel_reason TEXT,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    picking_at TIMESTAMPTZ,
+    dispatched_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_restock_requests_source CHECK (source IN ('MANUAL', 'LOW_STOCK', 'SENSOR')),
+    CONSTRAINT chk_restock_requests_status CHECK (status IN ('REQUESTED', 'PICKING', 'IN_TRANSIT', 'DELIVERED', 'CANCELLED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_restock_requests_festival ON restock_requests(festival_id, requested_at DESC);
+-- The picking queue and the low-stock scan look for the open requests of a stand
+CREATE INDEX IF NOT EXISTS idx_restock_requests_open ON restock_requests(stand_id, status) WHERE status IN ('REQUESTED', 'PICKING', 'IN_TRANSIT');
+
+-- Threshold at which a product is requested from the warehouse, checked every minute
+CREATE TABLE IF NOT EXISTS restock_rules (
+    product_id UUID PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    threshold INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_restock_rules_threshold CHECK (threshold >= 0),
+    CONSTRAINT chk_restock_rules_quantity CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_restock_rules_festival ON restock_rules(festival_id, stand_id);
+
+-- Stock added to the products of a stand by a delivery
+CREATE TABLE IF NOT EXISTS product_stock_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    request_id UUID REFERENCES restock_requests(id) ON DELETE SET NULL,
+    quantity INTEGER NOT NULL,
+    stock_before INTEGER NOT NULL,
+    stock_after INTEGER NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_stock_movements_product ON product_stock_movements(product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_stock_movements_request ON product_stock_movements(request_id);
+
+COMMENT ON TABLE restock_requests IS 'Restock requests of the stands to the central warehouse';
+COMMENT ON TABLE product_stock_movements IS 'Stock added to products by restock deliveries';
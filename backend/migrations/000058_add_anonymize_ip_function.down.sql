@@ -0,0 +1,3 @@
+-- Drop IP anonymization
+DROP INDEX IF EXISTS idx_impersonation_sessions_created_at;
+DROP FUNCTION IF EXISTS anonymize_ip(TEXT);
@@ -0,0 +1,20 @@
+-- Truncates an IP address to its network, like privacy.AnonymizeIP: the last octet of
+-- IPv4 addresses and the last 80 bits of IPv6 ones. Invalid addresses are dropped.
+-- Used by the worker to anonymize the client IPs stored past the retention period.
+CREATE OR REPLACE FUNCTION anonymize_ip(ip TEXT) RETURNS TEXT AS $$
+BEGIN
+    IF ip IS NULL OR ip = '' THEN
+        RETURN ip;
+    END IF;
+    IF family(ip::inet) = 4 THEN
+        RETURN host(network(set_masklen(ip::inet, 24)));
+    END IF;
+    RETURN host(network(set_masklen(ip::inet, 48)));
+EXCEPTION WHEN invalid_text_representation THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- The sweep looks for the sessions older than the retention period; the audit logs
+-- are already indexed by time
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_created_at ON public.impersonation_sessions(created_at);
@@ -0,0 +1,2 @@
+-- Drop user role changes
+DROP TABLE IF EXISTS user_role_changes;
@@ -0,0 +1,19 @@
+-- History of the platform role changes of the users, written with the
+-- AUTHZ_ROLE_CHANGE security event. Elevations to ADMIN or ORGANIZER record whether
+-- the administrator confirmed them with multi-factor authentication.
+CREATE TABLE IF NOT EXISTS user_role_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    old_role VARCHAR(20) NOT NULL,
+    new_role VARCHAR(20) NOT NULL,
+    reason TEXT,
+    mfa_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_role_changes_user ON user_role_changes(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_role_changes_created_at ON user_role_changes(created_at DESC);
+
+COMMENT ON TABLE user_role_changes IS 'Platform role changes of the users, with the administrator who made them';
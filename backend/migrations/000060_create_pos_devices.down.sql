@@ -0,0 +1,3 @@
+-- Drop POS devices
+DROP TABLE IF EXISTS pos_pairings;
+DROP TABLE IF EXISTS pos_devices;
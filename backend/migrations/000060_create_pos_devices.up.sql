@@ -0,0 +1,45 @@
+-- POS terminals paired to a stand by scanning a short-lived QR code, so staff never
+-- type passwords on shared hardware
+CREATE TABLE IF NOT EXISTS pos_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    platform VARCHAR(50),
+    app_version VARCHAR(50),
+    token_hash VARCHAR(64) NOT NULL,
+    token_prefix VARCHAR(20) NOT NULL,
+    paired_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    paired_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ,
+    unpaired_at TIMESTAMPTZ,
+    unpaired_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_pos_devices_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pos_devices_festival ON pos_devices(festival_id, stand_id);
+
+CREATE TABLE IF NOT EXISTS pos_pairings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    device_name VARCHAR(100),
+    code_hash VARCHAR(64) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    -- Set when the code is claimed, just before the device is created
+    device_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_pos_pairings_code_hash UNIQUE (code_hash)
+);
+
+-- The dashboard lists the codes that can still be scanned
+CREATE INDEX IF NOT EXISTS idx_pos_pairings_open ON pos_pairings(festival_id, expires_at) WHERE used_at IS NULL;
+
+COMMENT ON TABLE pos_devices IS 'POS terminals paired to a stand, authenticated with a device token until unpaired';
+COMMENT ON TABLE pos_pairings IS 'Single-use pairing codes shown as QR codes on the dashboard';
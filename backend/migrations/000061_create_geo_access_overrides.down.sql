@@ -0,0 +1,2 @@
+-- Drop geo access overrides
+DROP TABLE IF EXISTS geo_access_overrides;
@@ -0,0 +1,16 @@
+-- Networks and AS allowed or denied on the paths covered by the geo access rules,
+-- whatever their location, e.g. an administrator travelling outside the EU
+CREATE TABLE IF NOT EXISTS geo_access_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('NETWORK', 'ASN')),
+    value VARCHAR(50) NOT NULL,
+    effect VARCHAR(10) NOT NULL CHECK (effect IN ('ALLOW', 'DENY')),
+    reason TEXT,
+    expires_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_geo_access_overrides_expires ON geo_access_overrides(expires_at);
+
+COMMENT ON TABLE geo_access_overrides IS 'Runtime exceptions to the geo access rules, reloaded on every API instance';
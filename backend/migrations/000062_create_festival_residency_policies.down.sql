@@ -0,0 +1,2 @@
+-- Drop festival residency policies
+DROP TABLE IF EXISTS festival_residency_policies;
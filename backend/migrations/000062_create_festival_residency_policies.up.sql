@@ -0,0 +1,11 @@
+-- Data residency policy of each festival; festivals without a row are STANDARD
+CREATE TABLE IF NOT EXISTS festival_residency_policies (
+    festival_id UUID PRIMARY KEY REFERENCES festivals(id) ON DELETE CASCADE,
+    mode VARCHAR(20) NOT NULL DEFAULT 'STANDARD' CHECK (mode IN ('STANDARD', 'EU_ONLY')),
+    notes TEXT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE festival_residency_policies IS 'EU_ONLY pins report storage, email and SMS providers of a festival to EU regions';
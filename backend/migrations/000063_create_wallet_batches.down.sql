@@ -0,0 +1,4 @@
+COMMENT ON COLUMN transactions.type IS 'Transaction type: TOP_UP, CASH_IN, PURCHASE, REFUND, TRANSFER, CASH_OUT, MERGE';
+
+DROP TABLE IF EXISTS wallet_batch_items;
+DROP TABLE IF EXISTS wallet_batches;
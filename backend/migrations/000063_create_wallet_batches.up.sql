@@ -0,0 +1,53 @@
+-- Bulk credits and debits of the wallets of a festival, selected by a segment of the
+-- attendees or listed in a CSV file, processed by the worker in chunks
+CREATE TABLE IF NOT EXISTS wallet_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    operation VARCHAR(10) NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    reason VARCHAR(200) NOT NULL,
+    source VARCHAR(10) NOT NULL,
+    segment JSONB,
+    file_name VARCHAR(255),
+    skipped JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'QUEUED',
+    wallet_count INTEGER NOT NULL DEFAULT 0,
+    total_amount BIGINT NOT NULL DEFAULT 0,
+    chunks INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    succeeded_amount BIGINT NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_wallet_batches_operation CHECK (operation IN ('CREDIT', 'DEBIT')),
+    CONSTRAINT chk_wallet_batches_source CHECK (source IN ('SEGMENT', 'CSV')),
+    CONSTRAINT chk_wallet_batches_status CHECK (status IN ('QUEUED', 'PROCESSING', 'COMPLETED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_batches_festival_id ON wallet_batches(festival_id, created_at DESC);
+
+-- One row per wallet of a batch; the transaction ID is chosen upfront so that a chunk
+-- retried by the worker credits or debits each wallet once
+CREATE TABLE IF NOT EXISTS wallet_batch_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id UUID NOT NULL REFERENCES wallet_batches(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL DEFAULT 0,
+    chunk INTEGER NOT NULL,
+    amount BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    transaction_id UUID NOT NULL,
+    error TEXT,
+    processed_at TIMESTAMPTZ,
+    CONSTRAINT chk_wallet_batch_items_amount CHECK (amount > 0),
+    CONSTRAINT chk_wallet_batch_items_status CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_batch_items_chunk ON wallet_batch_items(batch_id, chunk, status);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_wallet_batch_items_transaction_id ON wallet_batch_items(transaction_id);
+
+COMMENT ON TABLE wallet_batches IS 'Bulk credits and debits of festival wallets, e.g. to compensate a cancelled show';
+COMMENT ON COLUMN transactions.type IS 'Transaction type: TOP_UP, CASH_IN, PURCHASE, REFUND, TRANSFER, CASH_OUT, MERGE, ADJUSTMENT';
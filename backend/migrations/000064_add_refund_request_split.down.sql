@@ -0,0 +1,2 @@
+ALTER TABLE refund_requests DROP COLUMN IF EXISTS cash_amount;
+ALTER TABLE refund_requests DROP COLUMN IF EXISTS card_amount;
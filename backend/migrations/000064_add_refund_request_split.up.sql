@@ -0,0 +1,39 @@
+-- Refund requests of wallet balances, routed back to the card and in cash in proportion
+-- to the top-ups of the wallet
+CREATE TABLE IF NOT EXISTS refund_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    net_amount BIGINT NOT NULL,
+    fee BIGINT NOT NULL DEFAULT 0,
+    reason TEXT,
+    iban VARCHAR(34),
+    bic VARCHAR(11),
+    account_holder VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    payment_method VARCHAR(20) DEFAULT 'bank_transfer',
+    stripe_refund_id VARCHAR(255),
+    processed_by UUID,
+    processed_at TIMESTAMPTZ,
+    rejection_note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_requests_wallet_id ON refund_requests(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_refund_requests_user_id ON refund_requests(user_id);
+CREATE INDEX IF NOT EXISTS idx_refund_requests_festival_id ON refund_requests(festival_id, status);
+
+ALTER TABLE refund_requests ADD COLUMN IF NOT EXISTS card_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE refund_requests ADD COLUMN IF NOT EXISTS cash_amount BIGINT NOT NULL DEFAULT 0;
+
+-- Refunds completed before the split went back whole by their payment method
+UPDATE refund_requests SET card_amount = net_amount
+WHERE status = 'COMPLETED' AND payment_method = 'stripe' AND card_amount = 0 AND cash_amount = 0;
+UPDATE refund_requests SET cash_amount = net_amount
+WHERE status = 'COMPLETED' AND payment_method <> 'stripe' AND card_amount = 0 AND cash_amount = 0;
+
+COMMENT ON COLUMN refund_requests.card_amount IS 'Part of the net amount going back to the card, in cents';
+COMMENT ON COLUMN refund_requests.cash_amount IS 'Part of the net amount handed back at the cash desk or by bank transfer, in cents';
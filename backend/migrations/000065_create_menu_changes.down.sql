@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_menu_changes_product_draft;
+DROP INDEX IF EXISTS idx_menu_changes_stand;
+DROP INDEX IF EXISTS idx_menu_changes_festival_status;
+DROP TABLE IF EXISTS menu_changes;
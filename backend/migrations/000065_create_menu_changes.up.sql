@@ -0,0 +1,40 @@
+-- Menu changes drafted by vendors, published once approved by an organizer
+CREATE TABLE IF NOT EXISTS menu_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    product_id UUID,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('CREATE', 'UPDATE', 'DELETE')),
+    product_name VARCHAR(255) NOT NULL DEFAULT '',
+    current_price BIGINT,
+    new_product JSONB,
+    product_changes JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'DRAFT'
+        CHECK (status IN ('DRAFT', 'PENDING', 'SCHEDULED', 'PUBLISHED', 'REJECTED', 'FAILED')),
+    publish_at TIMESTAMPTZ,
+    review_note TEXT,
+    error TEXT,
+    created_by UUID,
+    submitted_by UUID,
+    submitted_at TIMESTAMPTZ,
+    reviewed_by UUID,
+    reviewed_at TIMESTAMPTZ,
+    published_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (action = 'CREATE' OR product_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_changes_festival_status ON menu_changes(festival_id, status);
+CREATE INDEX IF NOT EXISTS idx_menu_changes_stand ON menu_changes(stand_id, status);
+
+-- A product has at most one draft update, which further edits are merged into
+CREATE UNIQUE INDEX IF NOT EXISTS idx_menu_changes_product_draft ON menu_changes(product_id)
+    WHERE action = 'UPDATE' AND status = 'DRAFT';
+
+COMMENT ON TABLE menu_changes IS 'Vendor changes to the menu of a stand, reaching the products once approved by an organizer';
+COMMENT ON COLUMN menu_changes.product_id IS 'Changed product; set on publication for creations';
+COMMENT ON COLUMN menu_changes.new_product IS 'Product added by a CREATE change';
+COMMENT ON COLUMN menu_changes.product_changes IS 'Fields changed by an UPDATE change';
+COMMENT ON COLUMN menu_changes.current_price IS 'Published price when the change was drafted, in cents';
+COMMENT ON COLUMN menu_changes.publish_at IS 'Publication time requested by the vendor or set by the organizer; SCHEDULED changes are published by the worker';
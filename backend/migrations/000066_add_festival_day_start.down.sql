@@ -0,0 +1,2 @@
+ALTER TABLE festivals DROP CONSTRAINT IF EXISTS festivals_day_starts_at_check;
+ALTER TABLE festivals DROP COLUMN IF EXISTS day_starts_at;
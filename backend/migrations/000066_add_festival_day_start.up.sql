@@ -0,0 +1,9 @@
+-- Local time the operational day of a festival starts at: sales made after midnight
+-- and before it belong to the previous day in daily stats, Z-reports and leaderboards.
+-- 06:00 is the boundary day closes used before it became configurable.
+ALTER TABLE festivals ADD COLUMN IF NOT EXISTS day_starts_at VARCHAR(5) NOT NULL DEFAULT '06:00';
+
+ALTER TABLE festivals ADD CONSTRAINT festivals_day_starts_at_check
+    CHECK (day_starts_at ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$');
+
+COMMENT ON COLUMN festivals.day_starts_at IS 'Local time (HH:MM) the operational day of the festival starts at';
@@ -0,0 +1,14 @@
+-- Organizer choice of the anonymized stats published on the festival website
+CREATE TABLE IF NOT EXISTS public_stats_settings (
+    festival_id UUID PRIMARY KEY REFERENCES festivals(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    metrics JSONB NOT NULL DEFAULT '[]',
+    top_limit INTEGER NOT NULL DEFAULT 5 CHECK (top_limit BETWEEN 1 AND 10),
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE public_stats_settings IS 'Opt-in public stats of a festival; the public endpoint answers 404 until enabled';
+COMMENT ON COLUMN public_stats_settings.metrics IS 'Published metrics, e.g. ["TICKETS_SOLD", "TOP_PRODUCTS"]';
+COMMENT ON COLUMN public_stats_settings.top_limit IS 'Length of the product and category rankings';
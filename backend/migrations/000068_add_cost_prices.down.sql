@@ -0,0 +1,5 @@
+ALTER TABLE stands DROP CONSTRAINT IF EXISTS stands_card_fee_percent_check;
+ALTER TABLE stands DROP COLUMN IF EXISTS card_fee_percent;
+ALTER TABLE product_stock_movements DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_cost_price_check;
+ALTER TABLE products DROP COLUMN IF EXISTS cost_price;
@@ -0,0 +1,18 @@
+-- Unit cost of goods of the products, for the gross margin in the stats and the vendor
+-- profitability report. Unknown until set by the vendor or a restock delivery.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price BIGINT;
+
+ALTER TABLE products ADD CONSTRAINT products_cost_price_check CHECK (cost_price >= 0);
+
+-- Cost of the units received by a delivery, averaged into the cost price of the product
+ALTER TABLE product_stock_movements ADD COLUMN IF NOT EXISTS unit_cost BIGINT;
+
+-- Card processing fees of a stand, deducted from its card sales in the profitability report
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS card_fee_percent NUMERIC(5,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE stands ADD CONSTRAINT stands_card_fee_percent_check
+    CHECK (card_fee_percent BETWEEN 0 AND 100);
+
+COMMENT ON COLUMN products.cost_price IS 'Unit cost of goods in cents, NULL when unknown';
+COMMENT ON COLUMN product_stock_movements.unit_cost IS 'Cost price of the units received, in cents';
+COMMENT ON COLUMN stands.card_fee_percent IS 'Card processing fees on the card sales of the stand, in percent';
@@ -0,0 +1,7 @@
+ALTER TABLE categories DROP COLUMN IF EXISTS description_translations;
+ALTER TABLE categories DROP COLUMN IF EXISTS name_translations;
+ALTER TABLE products DROP COLUMN IF EXISTS description_translations;
+ALTER TABLE products DROP COLUMN IF EXISTS name_translations;
+ALTER TABLE stands DROP COLUMN IF EXISTS description_translations;
+ALTER TABLE stands DROP COLUMN IF EXISTS name_translations;
+ALTER TABLE festivals DROP COLUMN IF EXISTS default_locale;
@@ -0,0 +1,16 @@
+-- Language the names and descriptions of a festival's stands, products and categories
+-- are written in. Their translations must always include it.
+ALTER TABLE festivals ADD COLUMN IF NOT EXISTS default_locale VARCHAR(16) NOT NULL DEFAULT 'en';
+
+-- Translations keyed by language tag, e.g. {"en": "Fries", "fr-BE": "Frites"}
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS name_translations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS description_translations JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS name_translations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS description_translations JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS name_translations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS description_translations JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN festivals.default_locale IS 'Language tag of the untranslated names and descriptions';
+COMMENT ON COLUMN products.name_translations IS 'Product name by language tag, including the festival default locale';
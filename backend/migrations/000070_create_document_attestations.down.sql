@@ -0,0 +1,7 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS content_hash;
+
+DROP TRIGGER IF EXISTS prevent_document_attestations_update_delete ON document_attestations;
+DROP INDEX IF EXISTS idx_document_attestations_content;
+DROP INDEX IF EXISTS idx_document_attestations_document;
+DROP INDEX IF EXISTS idx_document_attestations_sequence;
+DROP TABLE IF EXISTS document_attestations;
@@ -0,0 +1,36 @@
+-- Signed hash chain of the finalized financial documents of each festival: the
+-- Z-reports of day closes and the files of financial report exports. Each attestation
+-- embeds the record hash of the previous one, so that a changed, removed or reordered
+-- document breaks every later link.
+CREATE TABLE IF NOT EXISTS document_attestations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    sequence BIGINT NOT NULL CHECK (sequence > 0),
+    document_type VARCHAR(20) NOT NULL CHECK (document_type IN ('DAY_CLOSE', 'REPORT')),
+    document_id UUID NOT NULL,
+    document_number VARCHAR(255) NOT NULL DEFAULT '',
+    content_hash CHAR(64) NOT NULL,
+    previous_hash CHAR(64) NOT NULL,
+    record_hash CHAR(64) NOT NULL,
+    signature CHAR(64) NOT NULL,
+    key_fingerprint VARCHAR(32) NOT NULL,
+    signed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_attestations_sequence ON document_attestations(festival_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_document_attestations_document ON document_attestations(document_type, document_id);
+CREATE INDEX IF NOT EXISTS idx_document_attestations_content ON document_attestations(festival_id, content_hash);
+
+-- Attestations are append-only, like the day closes they seal
+CREATE TRIGGER prevent_document_attestations_update_delete
+    BEFORE UPDATE OR DELETE ON document_attestations
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_day_close_changes();
+
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+
+COMMENT ON TABLE document_attestations IS 'Append-only signed hash chain of the Z-reports and financial exports of each festival';
+COMMENT ON COLUMN document_attestations.previous_hash IS 'Record hash of the previous attestation of the festival, zeros for the first';
+COMMENT ON COLUMN document_attestations.signature IS 'HMAC-SHA256 of the record hash with a key derived from the server keyring';
+COMMENT ON COLUMN document_attestations.key_fingerprint IS 'Fingerprint of the keyring secret the signature was made with';
+COMMENT ON COLUMN reports.content_hash IS 'SHA-256 of the file of attested financial reports';
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_locker_rentals_wallet;
+DROP INDEX IF EXISTS idx_locker_rentals_festival;
+DROP INDEX IF EXISTS idx_locker_rentals_active_locker;
+DROP INDEX IF EXISTS idx_locker_rentals_ticket;
+DROP TABLE IF EXISTS locker_rentals;
+
+DROP INDEX IF EXISTS idx_lockers_festival;
+DROP INDEX IF EXISTS idx_lockers_number;
+DROP TABLE IF EXISTS lockers;
+
+DROP INDEX IF EXISTS idx_locker_zones_festival;
+DROP TABLE IF EXISTS locker_zones;
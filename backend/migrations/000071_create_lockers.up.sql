@@ -0,0 +1,64 @@
+-- Lockers and gear checks rented to attendees. A rental occupies one locker, is paid
+-- from the wallet of the attendee and is ended with the ticket code handed to them, or
+-- by staff when the ticket was lost.
+CREATE TABLE IF NOT EXISTS locker_zones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('LOCKER', 'GEAR_CHECK')),
+    location VARCHAR(255) NOT NULL DEFAULT '',
+    price BIGINT NOT NULL CHECK (price >= 0),
+    lost_ticket_fee BIGINT NOT NULL DEFAULT 0 CHECK (lost_ticket_fee >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_locker_zones_festival ON locker_zones(festival_id);
+
+CREATE TABLE IF NOT EXISTS lockers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    zone_id UUID NOT NULL REFERENCES locker_zones(id),
+    number VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE' CHECK (status IN ('AVAILABLE', 'OCCUPIED', 'OUT_OF_SERVICE')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lockers_number ON lockers(zone_id, number);
+CREATE INDEX IF NOT EXISTS idx_lockers_festival ON lockers(festival_id, zone_id, status);
+
+CREATE TABLE IF NOT EXISTS locker_rentals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    zone_id UUID NOT NULL REFERENCES locker_zones(id),
+    locker_id UUID NOT NULL REFERENCES lockers(id),
+    locker_number VARCHAR(30) NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    ticket_code VARCHAR(12) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('ACTIVE', 'RETURNED', 'LOST_TICKET', 'CANCELLED')),
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    transaction_id UUID,
+    lost_ticket_fee BIGINT NOT NULL DEFAULT 0 CHECK (lost_ticket_fee >= 0),
+    fee_transaction_id UUID,
+    override_reason TEXT NOT NULL DEFAULT '',
+    started_by UUID,
+    ended_by UUID,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    CHECK ((status = 'ACTIVE') = (ended_at IS NULL))
+);
+
+-- A ticket code opens a single active rental; codes are reused once rentals end
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locker_rentals_ticket ON locker_rentals(festival_id, ticket_code) WHERE status = 'ACTIVE';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locker_rentals_active_locker ON locker_rentals(locker_id) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_locker_rentals_festival ON locker_rentals(festival_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_locker_rentals_wallet ON locker_rentals(wallet_id);
+
+COMMENT ON TABLE locker_zones IS 'Banks of lockers and gear checks of a festival with the price of a rental';
+COMMENT ON COLUMN locker_zones.price IS 'Charged to the wallet when a rental starts, in cents';
+COMMENT ON COLUMN locker_zones.lost_ticket_fee IS 'Charged when staff release the items of a rental without its ticket, in cents';
+COMMENT ON COLUMN lockers.status IS 'OCCUPIED is set and cleared by rentals only';
+COMMENT ON TABLE locker_rentals IS 'Rentals of lockers paid from attendee wallets';
+COMMENT ON COLUMN locker_rentals.override_reason IS 'How staff checked the attendee owned the items of a lost ticket';
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_transport_pass_scans_pass;
+DROP INDEX IF EXISTS idx_transport_pass_scans_festival;
+DROP TABLE IF EXISTS transport_pass_scans;
+
+DROP INDEX IF EXISTS idx_transport_passes_user;
+DROP INDEX IF EXISTS idx_transport_passes_plate;
+DROP INDEX IF EXISTS idx_transport_passes_product;
+DROP INDEX IF EXISTS idx_transport_passes_code;
+DROP TABLE IF EXISTS transport_passes;
+
+DROP INDEX IF EXISTS idx_transport_products_festival;
+DROP TABLE IF EXISTS transport_products;
@@ -0,0 +1,69 @@
+-- Parking and shuttle passes sold to attendees. Each product has a capacity the passes
+-- sold cannot exceed; passes are paid from the wallet and scanned at the parking gates
+-- and shuttle stops, where every scan is recorded for traffic planning.
+CREATE TABLE IF NOT EXISTS transport_products (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('PARKING', 'SHUTTLE')),
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    location VARCHAR(255) NOT NULL DEFAULT '',
+    price BIGINT NOT NULL CHECK (price >= 0),
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    sold INTEGER NOT NULL DEFAULT 0,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_until TIMESTAMPTZ NOT NULL,
+    multi_use BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (sold BETWEEN 0 AND capacity),
+    CHECK (valid_until > valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transport_products_festival ON transport_products(festival_id);
+
+CREATE TABLE IF NOT EXISTS transport_passes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    product_id UUID NOT NULL REFERENCES transport_products(id),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('PARKING', 'SHUTTLE')),
+    user_id UUID NOT NULL REFERENCES users(id),
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    code VARCHAR(16) NOT NULL,
+    license_plate VARCHAR(20) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('VALID', 'USED', 'CANCELLED')),
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    transaction_id UUID,
+    first_used_at TIMESTAMPTZ,
+    purchased_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transport_passes_code ON transport_passes(festival_id, code);
+CREATE INDEX IF NOT EXISTS idx_transport_passes_product ON transport_passes(product_id, status);
+CREATE INDEX IF NOT EXISTS idx_transport_passes_plate ON transport_passes(festival_id, license_plate) WHERE license_plate <> '';
+CREATE INDEX IF NOT EXISTS idx_transport_passes_user ON transport_passes(festival_id, user_id);
+
+CREATE TABLE IF NOT EXISTS transport_pass_scans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    product_id UUID NOT NULL REFERENCES transport_products(id),
+    pass_id UUID NOT NULL REFERENCES transport_passes(id),
+    scan_type VARCHAR(20) NOT NULL CHECK (scan_type IN ('ENTRY', 'EXIT', 'CHECK')),
+    result VARCHAR(20) NOT NULL CHECK (result IN ('SUCCESS', 'ALREADY_USED', 'EXPIRED', 'INVALID')),
+    message TEXT NOT NULL DEFAULT '',
+    gate VARCHAR(100) NOT NULL DEFAULT '',
+    device_id VARCHAR(100) NOT NULL DEFAULT '',
+    scanned_by UUID,
+    scanned_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transport_pass_scans_festival ON transport_pass_scans(festival_id, scanned_at);
+CREATE INDEX IF NOT EXISTS idx_transport_pass_scans_pass ON transport_pass_scans(pass_id, scanned_at);
+
+COMMENT ON TABLE transport_products IS 'Parking and shuttle passes on sale at a festival';
+COMMENT ON COLUMN transport_products.sold IS 'Passes sold and not cancelled; only raised while below capacity';
+COMMENT ON COLUMN transport_products.multi_use IS 'Passes can be scanned in again after their first entry';
+COMMENT ON TABLE transport_passes IS 'Parking and shuttle passes paid from attendee wallets';
+COMMENT ON COLUMN transport_passes.code IS 'Printed code typed at the gates when the QR code cannot be read';
+COMMENT ON TABLE transport_pass_scans IS 'Gate scans of passes, accepted or refused, for traffic planning';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_custom_fields;
+ALTER TABLE orders DROP COLUMN IF EXISTS custom_fields;
+
+DROP INDEX IF EXISTS idx_order_custom_fields_key;
+DROP TABLE IF EXISTS order_custom_fields;
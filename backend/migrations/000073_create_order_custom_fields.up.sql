@@ -0,0 +1,34 @@
+-- Custom fields organizers ask for on the orders of their festival, e.g. a table
+-- number or an allergy note. Orders keep the values entered with the label of their
+-- field, so that changing or deactivating a field leaves past orders as they were.
+CREATE TABLE IF NOT EXISTS order_custom_fields (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id),
+    key VARCHAR(40) NOT NULL CHECK (key ~ '^[a-z][a-z0-9_]*$'),
+    label VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('TEXT', 'NUMBER', 'BOOLEAN', 'SELECT')),
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    options TEXT[],
+    max_length INTEGER NOT NULL DEFAULT 0 CHECK (max_length >= 0),
+    min DOUBLE PRECISION,
+    max DOUBLE PRECISION,
+    integer_only BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    print_on_ticket BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (min IS NULL OR max IS NULL OR min <= max)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_custom_fields_key ON order_custom_fields(festival_id, key);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '[]';
+
+-- Order lists filter on the values with containment queries
+CREATE INDEX IF NOT EXISTS idx_orders_custom_fields ON orders USING GIN (custom_fields jsonb_path_ops);
+
+COMMENT ON TABLE order_custom_fields IS 'Typed custom fields asked for on the orders of a festival';
+COMMENT ON COLUMN order_custom_fields.key IS 'Names the values on orders; cannot change';
+COMMENT ON COLUMN order_custom_fields.print_on_ticket IS 'Printed on the stand ticket of the order';
+COMMENT ON COLUMN orders.custom_fields IS 'Array of {key, label, value, printOnTicket} of the custom fields, values as normalized text';
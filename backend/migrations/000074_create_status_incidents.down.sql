@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_status_incident_updates_incident;
+DROP TABLE IF EXISTS status_incident_updates;
+
+DROP INDEX IF EXISTS idx_status_incidents_unresolved;
+DROP INDEX IF EXISTS idx_status_incidents_started_at;
+DROP TABLE IF EXISTS status_incidents;
@@ -0,0 +1,32 @@
+-- Incidents posted by platform admins on the public status feed. While unresolved, an
+-- incident sets the state of the components it affects from its impact.
+CREATE TABLE IF NOT EXISTS status_incidents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(200) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('INVESTIGATING', 'IDENTIFIED', 'MONITORING', 'RESOLVED')),
+    impact VARCHAR(20) NOT NULL CHECK (impact IN ('NONE', 'MINOR', 'MAJOR', 'CRITICAL')),
+    components TEXT[] NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_incidents_started_at ON status_incidents(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_status_incidents_unresolved ON status_incidents(started_at) WHERE status <> 'RESOLVED';
+
+CREATE TABLE IF NOT EXISTS status_incident_updates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    incident_id UUID NOT NULL REFERENCES status_incidents(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('INVESTIGATING', 'IDENTIFIED', 'MONITORING', 'RESOLVED')),
+    message TEXT NOT NULL,
+    posted_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_incident_updates_incident ON status_incident_updates(incident_id, created_at DESC);
+
+COMMENT ON TABLE status_incidents IS 'Incidents shown on the public status feed';
+COMMENT ON COLUMN status_incidents.components IS 'Keys of the affected components: api or the name of a health check';
+COMMENT ON TABLE status_incident_updates IS 'Progress messages posted on an incident';
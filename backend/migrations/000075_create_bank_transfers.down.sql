@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_bank_transfers_order;
+DROP INDEX IF EXISTS idx_bank_transfers_unmatched;
+DROP INDEX IF EXISTS idx_bank_transfers_festival;
+DROP TABLE IF EXISTS bank_transfers;
+
+DROP INDEX IF EXISTS idx_bank_transfer_orders_festival;
+DROP TABLE IF EXISTS bank_transfer_orders;
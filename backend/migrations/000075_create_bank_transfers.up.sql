@@ -0,0 +1,58 @@
+-- Wallet top-ups paid by bank transfer. An order credits one or many wallets, e.g. the
+-- group booking of a company, once a transfer quoting its reference or paid to its
+-- virtual IBAN is received.
+CREATE TABLE IF NOT EXISTS bank_transfer_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    reference VARCHAR(25) NOT NULL UNIQUE,
+    virtual_iban VARCHAR(34) UNIQUE,
+    payer_name VARCHAR(140) NOT NULL,
+    payer_email VARCHAR(255),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    lines JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PAID', 'CANCELLED')),
+    transfer_id UUID,
+    expires_at TIMESTAMPTZ NOT NULL,
+    paid_at TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bank_transfer_orders_festival ON bank_transfer_orders(festival_id, created_at DESC);
+
+-- Incoming transfers to the collection account, imported from camt.053 or MT940
+-- statements or notified by the virtual IBAN provider
+CREATE TABLE IF NOT EXISTS bank_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('CAMT053', 'MT940', 'PROVIDER')),
+    bank_reference VARCHAR(100) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    booked_at TIMESTAMPTZ NOT NULL,
+    payer_name VARCHAR(140),
+    payer_iban VARCHAR(34),
+    credited_iban VARCHAR(34),
+    remittance_info TEXT,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('MATCHED', 'UNMATCHED', 'DISMISSED')),
+    order_id UUID REFERENCES bank_transfer_orders(id) ON DELETE SET NULL,
+    reason TEXT,
+    reviewed_by UUID,
+    review_note TEXT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (festival_id, bank_reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bank_transfers_festival ON bank_transfers(festival_id, booked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bank_transfers_unmatched ON bank_transfers(festival_id) WHERE status = 'UNMATCHED';
+CREATE INDEX IF NOT EXISTS idx_bank_transfers_order ON bank_transfers(order_id);
+
+COMMENT ON TABLE bank_transfer_orders IS 'Wallet top-ups paid by bank transfer';
+COMMENT ON COLUMN bank_transfer_orders.reference IS 'ISO 11649 creditor reference quoted by the payer';
+COMMENT ON COLUMN bank_transfer_orders.lines IS 'Wallets credited with their amount and the upfront transaction ID of the credit';
+COMMENT ON TABLE bank_transfers IS 'Incoming bank transfers, matched to top-up orders or flagged for review';
+COMMENT ON COLUMN bank_transfers.bank_reference IS 'Bank reference, or a fingerprint of the entry, so that statements can be imported again';
@@ -0,0 +1,10 @@
+COMMENT ON COLUMN wallets.frozen_until IS NULL;
+COMMENT ON COLUMN wallets.freeze_reason IS NULL;
+
+DROP INDEX IF EXISTS idx_wallets_frozen_until;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen_until;
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen_by;
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS freeze_note;
+ALTER TABLE wallets DROP COLUMN IF EXISTS freeze_reason;
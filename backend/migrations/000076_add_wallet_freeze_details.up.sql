@@ -0,0 +1,12 @@
+-- Why and by whom a wallet was frozen, and when it is unfrozen automatically
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS freeze_reason VARCHAR(30);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS freeze_note TEXT;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen_by UUID;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen_until TIMESTAMP WITH TIME ZONE;
+
+-- Frozen wallets due for an automatic unfreeze
+CREATE INDEX IF NOT EXISTS idx_wallets_frozen_until ON wallets(frozen_until) WHERE status = 'FROZEN' AND frozen_until IS NOT NULL;
+
+COMMENT ON COLUMN wallets.freeze_reason IS 'LOST_WRISTBAND, STOLEN, SUSPECTED_FRAUD, CHARGEBACK, HOLDER_REQUEST or OTHER while frozen';
+COMMENT ON COLUMN wallets.frozen_until IS 'The wallet is unfrozen automatically at this time, NULL to stay frozen until unfrozen by staff';
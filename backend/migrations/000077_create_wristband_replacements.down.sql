@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wristband_replacements;
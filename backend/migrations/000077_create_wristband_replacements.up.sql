@@ -0,0 +1,27 @@
+-- Wristbands replaced at the help desk, e.g. after a loss. The wallet, its balance and
+-- its history stay the same; the old wristbands are revoked and a new one is linked.
+-- Kept as the audit trail of who replaced what, and as the list of wristband UIDs POS
+-- devices refuse while offline.
+CREATE TABLE IF NOT EXISTS wristband_replacements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    old_uids TEXT[] NOT NULL DEFAULT '{}',
+    new_uid VARCHAR(64) NOT NULL,
+    reason VARCHAR(30) NOT NULL,
+    note TEXT,
+    qr_generation INTEGER NOT NULL DEFAULT 0,
+    claim_code_reissued BOOLEAN NOT NULL DEFAULT FALSE,
+    unfrozen BOOLEAN NOT NULL DEFAULT FALSE,
+    replaced_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_wristband_replacements_reason CHECK (reason IN ('LOST', 'STOLEN', 'DAMAGED', 'OTHER'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_wristband_replacements_wallet_id ON wristband_replacements(wallet_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_wristband_replacements_festival_id ON wristband_replacements(festival_id);
+
+COMMENT ON TABLE wristband_replacements IS 'Wristbands of a wallet revoked and replaced by a new one, balance preserved';
+COMMENT ON COLUMN wristband_replacements.old_uids IS 'UIDs of the wristbands revoked, refused by POS devices from then on';
+COMMENT ON COLUMN wristband_replacements.qr_generation IS 'QR generation of the wallet after the replacement; codes below it are refused';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS test_clocks;
+
+ALTER TABLE festivals DROP COLUMN IF EXISTS sandbox;
@@ -0,0 +1,18 @@
+-- Sandbox festivals are test festivals, e.g. the demo festivals, which can run on a
+-- test clock. Set at creation only.
+ALTER TABLE festivals ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Virtual time of a sandbox festival. Its scheduled jobs run at this time instead of the
+-- wall time; it stays frozen until advanced, so that tests and demos can fast-forward
+-- price list changes, auto-cancels of unpaid orders and scheduled wallet unfreezes.
+CREATE TABLE IF NOT EXISTS test_clocks (
+    festival_id UUID PRIMARY KEY REFERENCES festivals(id) ON DELETE CASCADE,
+    frozen_time TIMESTAMPTZ NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    advanced_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON COLUMN festivals.sandbox IS 'Test festival, which can run on a test clock';
+COMMENT ON TABLE test_clocks IS 'Virtual time the scheduled jobs of a sandbox festival run at';
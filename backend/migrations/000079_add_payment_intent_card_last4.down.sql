@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_intents_festival_card;
+
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS card_last4;
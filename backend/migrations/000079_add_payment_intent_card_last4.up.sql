@@ -0,0 +1,8 @@
+-- Last digits of the card charged for a wallet top-up, from the charge.succeeded
+-- webhook. Support staff find the wallet of an attendee by them.
+ALTER TABLE payment_intents ADD COLUMN IF NOT EXISTS card_last4 VARCHAR(4);
+
+CREATE INDEX IF NOT EXISTS idx_payment_intents_festival_card ON payment_intents(festival_id, card_last4)
+    WHERE card_last4 IS NOT NULL;
+
+COMMENT ON COLUMN payment_intents.card_last4 IS 'Last 4 digits of the card charged, searched by support staff';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_settlement_adjustments_festival;
+DROP INDEX IF EXISTS idx_settlement_adjustments_stand;
+DROP TABLE IF EXISTS settlement_adjustments;
+
+DROP INDEX IF EXISTS idx_commission_rules_festival;
+DROP TABLE IF EXISTS commission_rules;
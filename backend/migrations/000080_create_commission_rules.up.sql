@@ -0,0 +1,39 @@
+-- Commission percent of the orders taken within a window, for one stand or for every
+-- stand of the festival (stand_id NULL), e.g. a 0% fee holiday on opening night. A stand
+-- rule takes precedence over a festival-wide one; rules of the same scope do not overlap.
+CREATE TABLE IF NOT EXISTS commission_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID REFERENCES stands(id) ON DELETE CASCADE,
+    label VARCHAR(100) NOT NULL,
+    percent NUMERIC(5,2) NOT NULL CHECK (percent BETWEEN 0 AND 100),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commission_rules_festival ON commission_rules(festival_id, starts_at);
+
+-- Corrections of what a stand is owed for a day, shown on the statements covering the
+-- day without changing its sales. Append-only: a wrong adjustment is reversed by another.
+CREATE TABLE IF NOT EXISTS settlement_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    reason TEXT NOT NULL,
+    rule_id UUID REFERENCES commission_rules(id),
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_adjustments_stand ON settlement_adjustments(stand_id, date);
+CREATE INDEX IF NOT EXISTS idx_settlement_adjustments_festival ON settlement_adjustments(festival_id, date);
+
+COMMENT ON COLUMN commission_rules.created_at IS 'Rules apply to the orders taken after their creation; earlier orders are corrected with settlement adjustments';
+COMMENT ON COLUMN settlement_adjustments.date IS 'Festival-local operational day corrected';
+COMMENT ON COLUMN settlement_adjustments.amount IS 'Owed to the vendor in cents, negative when owed by the vendor';
+COMMENT ON COLUMN settlement_adjustments.rule_id IS 'Commission rule applied after the fact';
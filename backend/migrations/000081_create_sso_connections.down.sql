@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sso_login_states_expires;
+DROP TABLE IF EXISTS sso_login_states;
+
+DROP INDEX IF EXISTS idx_sso_connections_email_domains;
+DROP TABLE IF EXISTS sso_connections;
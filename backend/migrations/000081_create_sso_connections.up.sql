@@ -0,0 +1,39 @@
+-- OpenID Connect provider of an organization, e.g. its Azure AD tenant or Google
+-- Workspace. Members whose email is in one of its domains sign in with it and get the
+-- role their groups map to; no two connections share a domain.
+CREATE TABLE IF NOT EXISTS sso_connections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    email_domains JSONB NOT NULL,
+    issuer VARCHAR(500) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret_encrypted TEXT NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    groups_claim VARCHAR(100) NOT NULL DEFAULT 'groups',
+    role_mappings JSONB NOT NULL DEFAULT '[]',
+    default_role VARCHAR(20) NOT NULL DEFAULT '' CHECK (default_role IN ('', 'ORGANIZER', 'STAFF', 'USER')),
+    festival_ids JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_connections_email_domains ON sso_connections USING GIN (email_domains);
+
+-- Sign-ins sent to a provider and not completed yet, taken once by the callback
+CREATE TABLE IF NOT EXISTS sso_login_states (
+    id VARCHAR(64) PRIMARY KEY,
+    connection_id UUID NOT NULL REFERENCES sso_connections(id) ON DELETE CASCADE,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    redirect TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_login_states_expires ON sso_login_states(expires_at);
+
+COMMENT ON TABLE sso_connections IS 'OpenID Connect providers organizations sign their members in with';
+COMMENT ON COLUMN sso_connections.client_secret_encrypted IS 'Client secret encrypted with SSO_ENCRYPTION_KEY';
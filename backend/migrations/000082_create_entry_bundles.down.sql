@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_bundle_purchases_user;
+DROP INDEX IF EXISTS idx_bundle_purchases_festival;
+DROP TABLE IF EXISTS bundle_purchases;
+
+DROP INDEX IF EXISTS idx_entry_bundles_festival;
+DROP TABLE IF EXISTS entry_bundles;
+
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS credit_amount;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS ticket_amount;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,61 @@
+-- What each payment intent pays for, so reports split the revenue between ticketing and
+-- cashless. Wallet top-ups credit their whole amount, ticket purchases none of it, and
+-- entry bundles pay for a ticket and a wallet credit at once.
+ALTER TABLE payment_intents ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'TOP_UP';
+ALTER TABLE payment_intents ADD COLUMN IF NOT EXISTS ticket_amount BIGINT NOT NULL DEFAULT 0 CHECK (ticket_amount >= 0);
+ALTER TABLE payment_intents ADD COLUMN IF NOT EXISTS credit_amount BIGINT NOT NULL DEFAULT 0 CHECK (credit_amount >= 0);
+
+UPDATE payment_intents SET kind = 'TICKET', ticket_amount = amount
+    WHERE wallet_id IS NULL OR wallet_id = '00000000-0000-0000-0000-000000000000';
+UPDATE payment_intents SET credit_amount = amount WHERE kind = 'TOP_UP';
+
+-- Entry bundles sold by a festival: a ticket of a ticket type and a wallet credit, paid
+-- together for the price of the ticket plus the credit
+CREATE TABLE IF NOT EXISTS entry_bundles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id) ON DELETE RESTRICT,
+    credit_amount BIGINT NOT NULL CHECK (credit_amount > 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_bundles_festival ON entry_bundles(festival_id);
+
+-- Purchases of entry bundles, one per payment intent. The ticket and the wallet credit
+-- are created together once the payment succeeds, and unwound together by a refund.
+CREATE TABLE IF NOT EXISTS bundle_purchases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bundle_id UUID NOT NULL REFERENCES entry_bundles(id) ON DELETE RESTRICT,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    stripe_intent_id VARCHAR(255) NOT NULL UNIQUE,
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id) ON DELETE RESTRICT,
+    ticket_id UUID REFERENCES tickets(id) ON DELETE SET NULL,
+    credit_transaction_id UUID,
+    ticket_amount BIGINT NOT NULL CHECK (ticket_amount >= 0),
+    credit_amount BIGINT NOT NULL CHECK (credit_amount > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'eur',
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    refunded_ticket_amount BIGINT NOT NULL DEFAULT 0,
+    refunded_credit_amount BIGINT NOT NULL DEFAULT 0,
+    stripe_refund_id VARCHAR(255),
+    failure_reason TEXT,
+    completed_at TIMESTAMPTZ,
+    refunded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_purchases_festival ON bundle_purchases(festival_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bundle_purchases_user ON bundle_purchases(user_id);
+
+COMMENT ON COLUMN payment_intents.kind IS 'What the payment is for: TOP_UP, TICKET or BUNDLE';
+COMMENT ON COLUMN payment_intents.ticket_amount IS 'Part of the amount paying for tickets, in cents';
+COMMENT ON COLUMN payment_intents.credit_amount IS 'Part of the amount credited to the wallet, in cents';
+COMMENT ON COLUMN bundle_purchases.status IS 'PENDING, COMPLETED, FAILED (refunded without a ticket or credit), REFUND_PENDING or REFUNDED';
+COMMENT ON COLUMN bundle_purchases.refunded_credit_amount IS 'Credit taken back from the wallet by the refund, at most what was left of it';
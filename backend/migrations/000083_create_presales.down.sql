@@ -0,0 +1,9 @@
+COMMENT ON COLUMN payment_intents.kind IS 'What the payment is for: TOP_UP, TICKET or BUNDLE';
+
+DROP INDEX IF EXISTS idx_wallet_presales_held;
+DROP INDEX IF EXISTS idx_wallet_presales_user;
+DROP INDEX IF EXISTS idx_wallet_presales_festival;
+DROP TABLE IF EXISTS wallet_presales;
+
+DROP INDEX IF EXISTS idx_presale_campaigns_festival;
+DROP TABLE IF EXISTS presale_campaigns;
@@ -0,0 +1,46 @@
+-- Bonus campaigns on the wallet top-ups bought before the festival gates open, e.g. 5 EUR
+-- free for a 50 EUR top-up, or for every 50 EUR when repeated
+CREATE TABLE IF NOT EXISTS presale_campaigns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    min_amount BIGINT NOT NULL CHECK (min_amount > 0),
+    bonus_amount BIGINT NOT NULL CHECK (bonus_amount > 0),
+    repeat BOOLEAN NOT NULL DEFAULT FALSE,
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_presale_campaigns_festival ON presale_campaigns(festival_id);
+
+-- Wallet top-ups paid by card before the gates open, one per payment intent. The money is
+-- held until the gates open, when the top-up and its bonus are credited together.
+CREATE TABLE IF NOT EXISTS wallet_presales (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    campaign_id UUID REFERENCES presale_campaigns(id) ON DELETE SET NULL,
+    stripe_intent_id VARCHAR(255) NOT NULL UNIQUE,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    bonus_amount BIGINT NOT NULL DEFAULT 0 CHECK (bonus_amount >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    top_up_transaction_id UUID,
+    bonus_transaction_id UUID,
+    paid_at TIMESTAMPTZ,
+    activated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_presales_festival ON wallet_presales(festival_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_wallet_presales_user ON wallet_presales(user_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_presales_held ON wallet_presales(festival_id) WHERE status = 'PAID';
+
+COMMENT ON COLUMN payment_intents.kind IS 'What the payment is for: TOP_UP, TICKET, BUNDLE or PRESALE';
+COMMENT ON COLUMN wallet_presales.status IS 'PENDING (waiting for the payment), PAID (held until the gates open) or ACTIVATED (credited)';
+COMMENT ON COLUMN wallet_presales.bonus_amount IS 'Credited on top of the top-up as an adjustment, not paid for';
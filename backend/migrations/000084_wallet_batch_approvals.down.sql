@@ -0,0 +1,23 @@
+DROP INDEX IF EXISTS idx_wallet_batch_events_batch;
+DROP TABLE IF EXISTS wallet_batch_events;
+
+DROP INDEX IF EXISTS idx_wallet_batches_pending_approval;
+
+-- Batches and items never processed cannot be represented without the approval workflow
+DELETE FROM wallet_batches WHERE status IN ('PENDING_APPROVAL', 'REJECTED');
+DELETE FROM wallet_batch_items WHERE status = 'REJECTED';
+
+ALTER TABLE wallet_batch_items DROP CONSTRAINT IF EXISTS chk_wallet_batch_items_status;
+ALTER TABLE wallet_batch_items ADD CONSTRAINT chk_wallet_batch_items_status
+    CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED'));
+
+ALTER TABLE wallet_batches DROP CONSTRAINT IF EXISTS chk_wallet_batches_status;
+ALTER TABLE wallet_batches ADD CONSTRAINT chk_wallet_batches_status
+    CHECK (status IN ('QUEUED', 'PROCESSING', 'COMPLETED'));
+
+ALTER TABLE wallet_batches DROP COLUMN IF EXISTS review_note;
+ALTER TABLE wallet_batches DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE wallet_batches DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE wallet_batches DROP COLUMN IF EXISTS rejected_amount;
+ALTER TABLE wallet_batches DROP COLUMN IF EXISTS rejected;
+ALTER TABLE wallet_batches DROP COLUMN IF EXISTS approval_required;
@@ -0,0 +1,38 @@
+-- Four-eyes approval of the large wallet batches: a batch totalling at least the
+-- approval threshold waits for another organizer than its creator, who may leave some
+-- of its wallets out, before being queued for the worker
+ALTER TABLE wallet_batches ADD COLUMN IF NOT EXISTS approval_required BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE wallet_batches ADD COLUMN IF NOT EXISTS rejected INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE wallet_batches ADD COLUMN IF NOT EXISTS rejected_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE wallet_batches ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE wallet_batches ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ;
+ALTER TABLE wallet_batches ADD COLUMN IF NOT EXISTS review_note VARCHAR(500);
+
+ALTER TABLE wallet_batches DROP CONSTRAINT IF EXISTS chk_wallet_batches_status;
+ALTER TABLE wallet_batches ADD CONSTRAINT chk_wallet_batches_status
+    CHECK (status IN ('PENDING_APPROVAL', 'REJECTED', 'QUEUED', 'PROCESSING', 'COMPLETED'));
+
+ALTER TABLE wallet_batch_items DROP CONSTRAINT IF EXISTS chk_wallet_batch_items_status;
+ALTER TABLE wallet_batch_items ADD CONSTRAINT chk_wallet_batch_items_status
+    CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED', 'REJECTED'));
+
+CREATE INDEX IF NOT EXISTS idx_wallet_batches_pending_approval ON wallet_batches(festival_id, created_at)
+    WHERE status = 'PENDING_APPROVAL';
+
+-- History of each batch: its creation, the wallets rejected by the reviewer and the
+-- decision, with who did it
+CREATE TABLE IF NOT EXISTS wallet_batch_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id UUID NOT NULL REFERENCES wallet_batches(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    note VARCHAR(500),
+    items INTEGER NOT NULL DEFAULT 0,
+    amount BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_wallet_batch_events_action CHECK (action IN ('CREATED', 'ITEMS_REJECTED', 'APPROVED', 'REJECTED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_batch_events_batch ON wallet_batch_events(batch_id, created_at);
+
+COMMENT ON COLUMN wallet_batches.rejected IS 'Wallets left out by the reviewer, all of them when the batch is rejected';
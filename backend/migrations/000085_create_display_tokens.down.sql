@@ -0,0 +1,3 @@
+-- Drop display tokens
+DROP INDEX IF EXISTS idx_orders_stand_ready_at;
+DROP TABLE IF EXISTS display_tokens;
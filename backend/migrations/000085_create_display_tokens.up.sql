@@ -0,0 +1,25 @@
+-- Read-only tokens of the digital menu boards, bound to a stand and revocable
+CREATE TABLE IF NOT EXISTS display_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    stand_id UUID NOT NULL REFERENCES stands(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    token_prefix VARCHAR(20) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    revoked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_display_tokens_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_display_tokens_festival ON display_tokens(festival_id, stand_id);
+
+-- The boards poll the orders of their stand marked ready in the last minutes
+CREATE INDEX IF NOT EXISTS idx_orders_stand_ready_at ON orders(stand_id, ready_at DESC)
+    WHERE ready_at IS NOT NULL;
+
+COMMENT ON TABLE display_tokens IS 'Tokens of the menu boards, reading the menu, wait time and now-serving numbers of one stand until revoked';
@@ -0,0 +1,11 @@
+-- Drop legacy imports
+DROP INDEX IF EXISTS idx_products_legacy_import;
+DROP INDEX IF EXISTS idx_transactions_legacy_import;
+DROP INDEX IF EXISTS idx_wallets_legacy_import;
+
+ALTER TABLE products DROP COLUMN IF EXISTS legacy_import_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS legacy_import_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS legacy_import_id;
+
+DROP TABLE IF EXISTS legacy_import_records;
+DROP TABLE IF EXISTS legacy_imports;
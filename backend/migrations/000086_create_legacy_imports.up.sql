@@ -0,0 +1,45 @@
+-- Files imported from the previous cashless provider of a festival
+CREATE TABLE IF NOT EXISTS legacy_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255),
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    imported INTEGER NOT NULL DEFAULT 0,
+    already_imported INTEGER NOT NULL DEFAULT 0,
+    invalid INTEGER NOT NULL DEFAULT 0,
+    total_amount BIGINT NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_legacy_imports_kind CHECK (kind IN ('WALLETS', 'TRANSACTIONS', 'PRODUCTS'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_legacy_imports_festival ON legacy_imports(festival_id, created_at DESC);
+
+-- The record created for each external ID, so re-running an import skips it
+CREATE TABLE IF NOT EXISTS legacy_import_records (
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    entity_id UUID NOT NULL,
+    import_id UUID NOT NULL REFERENCES legacy_imports(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (festival_id, source, kind, external_id)
+);
+
+-- Provenance of the imported records, shown as their source in the reports
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS legacy_import_id UUID REFERENCES legacy_imports(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS legacy_import_id UUID REFERENCES legacy_imports(id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS legacy_import_id UUID REFERENCES legacy_imports(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_wallets_legacy_import ON wallets(legacy_import_id) WHERE legacy_import_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_legacy_import ON transactions(legacy_import_id) WHERE legacy_import_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_products_legacy_import ON products(legacy_import_id) WHERE legacy_import_id IS NOT NULL;
+
+COMMENT ON TABLE legacy_imports IS 'Wallets, transactions and products imported from the CSV exports of a previous cashless provider';
+COMMENT ON COLUMN transactions.legacy_import_id IS 'Legacy import the transaction comes from; imported history has the IMPORTED status and never moved a balance';
@@ -0,0 +1,3 @@
+-- Drop retention policies
+DROP TABLE IF EXISTS retention_executions;
+DROP TABLE IF EXISTS retention_policies;
@@ -0,0 +1,33 @@
+-- Retention a festival set for a data class, replacing its default
+CREATE TABLE IF NOT EXISTS retention_policies (
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    class VARCHAR(30) NOT NULL,
+    retention_days INTEGER NOT NULL CHECK (retention_days > 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (festival_id, class),
+    CONSTRAINT chk_retention_policies_class CHECK (class IN ('TRANSACTIONS', 'ANALYTICS_EVENTS', 'LOCATION_PINGS', 'AUDIT_LOGS'))
+);
+
+-- Each enforcement of a policy by the daily sweep, kept as proof of compliance. Not
+-- cascaded with the festival: the report must outlive the data it purged.
+CREATE TABLE IF NOT EXISTS retention_executions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID,
+    class VARCHAR(30) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('PURGE', 'ANONYMIZE')),
+    retention_days INTEGER NOT NULL,
+    cutoff TIMESTAMPTZ NOT NULL,
+    affected BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('SUCCEEDED', 'FAILED')),
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_executions_festival ON retention_executions(festival_id, class, started_at DESC);
+
+COMMENT ON TABLE retention_policies IS 'Retention in days of the data classes of a festival; classes without a row keep their default';
+COMMENT ON TABLE retention_executions IS 'Purges and anonymizations of the data past its retention, one row per festival and class per sweep';
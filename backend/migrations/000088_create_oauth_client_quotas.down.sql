@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_oauth_client_daily_usage_festival;
+DROP TABLE IF EXISTS oauth_client_daily_usage;
+
+ALTER TABLE oauth_client_usage DROP COLUMN IF EXISTS quota_exceeded;
+
+ALTER TABLE oauth_clients
+    DROP COLUMN IF EXISTS enforce_quota,
+    DROP COLUMN IF EXISTS quota_warning_percent,
+    DROP COLUMN IF EXISTS monthly_quota;
@@ -0,0 +1,26 @@
+-- Contract quotas of the OAuth clients, counted per UTC calendar month
+ALTER TABLE oauth_clients
+    ADD COLUMN IF NOT EXISTS monthly_quota BIGINT CHECK (monthly_quota > 0),
+    ADD COLUMN IF NOT EXISTS quota_warning_percent INTEGER NOT NULL DEFAULT 80 CHECK (quota_warning_percent BETWEEN 1 AND 100),
+    ADD COLUMN IF NOT EXISTS enforce_quota BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE oauth_client_usage
+    ADD COLUMN IF NOT EXISTS quota_exceeded BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS oauth_client_daily_usage (
+    client_id UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    endpoint_class VARCHAR(30) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    throttled BIGINT NOT NULL DEFAULT 0,
+    quota_exceeded BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (client_id, day, endpoint_class)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_client_daily_usage_festival ON oauth_client_daily_usage(festival_id, day);
+
+COMMENT ON COLUMN oauth_clients.monthly_quota IS 'Requests per UTC calendar month of the contract, none when NULL';
+COMMENT ON COLUMN oauth_clients.enforce_quota IS 'Requests over the quota are refused with QUOTA_EXCEEDED, otherwise only reported';
+COMMENT ON TABLE oauth_client_daily_usage IS 'Daily metering of the requests of the clients per endpoint class, which quotas are counted on';
@@ -0,0 +1,6 @@
+COMMENT ON COLUMN transactions.type IS 'Transaction type: TOP_UP, CASH_IN, PURCHASE, REFUND, TRANSFER, CASH_OUT, MERGE, ADJUSTMENT';
+
+DROP TABLE IF EXISTS donations;
+DROP TABLE IF EXISTS donation_settlements;
+DROP TABLE IF EXISTS donation_opt_ins;
+DROP TABLE IF EXISTS donation_configs;
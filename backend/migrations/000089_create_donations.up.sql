@@ -0,0 +1,62 @@
+-- Round-up donations: attendees who opt in round every purchase up to the next euro
+-- for the charity their festival chose
+CREATE TABLE IF NOT EXISTS donation_configs (
+    festival_id UUID PRIMARY KEY REFERENCES festivals(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    charity_name VARCHAR(200) NOT NULL DEFAULT '',
+    charity_description TEXT NOT NULL DEFAULT '',
+    charity_url VARCHAR(500) NOT NULL DEFAULT '',
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS donation_opt_ins (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets(id) ON DELETE CASCADE,
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_donation_opt_ins_festival_id ON donation_opt_ins(festival_id);
+
+CREATE TABLE IF NOT EXISTS donation_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    charity_name VARCHAR(200) NOT NULL,
+    amount BIGINT NOT NULL,
+    donations BIGINT NOT NULL,
+    donors BIGINT NOT NULL,
+    "from" TIMESTAMPTZ NOT NULL,
+    "to" TIMESTAMPTZ NOT NULL,
+    reference VARCHAR(100) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    settled_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_donation_settlements_festival_id ON donation_settlements(festival_id, created_at);
+
+-- The ledger: one row per purchase rounded up, pointing at the DONATION transaction
+-- that debited the round-up from the wallet
+CREATE TABLE IF NOT EXISTS donations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    purchase_id UUID NOT NULL,
+    transaction_id UUID NOT NULL,
+    amount BIGINT NOT NULL,
+    charity_name VARCHAR(200) NOT NULL,
+    settlement_id UUID REFERENCES donation_settlements(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_donations_amount CHECK (amount > 0 AND amount < 100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_donations_festival_id ON donations(festival_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_donations_wallet_id ON donations(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_donations_unsettled ON donations(festival_id) WHERE settlement_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_donations_transaction_id ON donations(transaction_id);
+
+COMMENT ON TABLE donation_configs IS 'Round-up program of a festival and the charity the round-ups go to';
+COMMENT ON TABLE donations IS 'Round-up donation ledger, settled with the charity by donation_settlements';
+COMMENT ON COLUMN transactions.type IS 'Transaction type: TOP_UP, CASH_IN, PURCHASE, REFUND, TRANSFER, CASH_OUT, MERGE, ADJUSTMENT, DONATION';
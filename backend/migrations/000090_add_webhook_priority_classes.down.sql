@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_delivered;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS priority_class;
+
+ALTER TABLE webhook_configs DROP CONSTRAINT IF EXISTS chk_webhook_configs_priority_class;
+ALTER TABLE webhook_configs DROP COLUMN IF EXISTS priority_class;
@@ -0,0 +1,16 @@
+-- Priority class of the webhooks, the deliveries of each class waiting in their own
+-- queue. The payment events of the PRIORITY webhooks, which come with the paid plans,
+-- are delivered first when deliveries pile up.
+ALTER TABLE webhook_configs ADD COLUMN IF NOT EXISTS priority_class VARCHAR(20) NOT NULL DEFAULT 'STANDARD';
+ALTER TABLE webhook_configs ADD CONSTRAINT chk_webhook_configs_priority_class
+    CHECK (priority_class IN ('PRIORITY', 'STANDARD', 'BULK'));
+
+-- Class of the webhook when the event occurred, so that retries keep their queue
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS priority_class VARCHAR(20) NOT NULL DEFAULT 'STANDARD';
+
+-- Delivery latency of a webhook, from the event to its successful delivery
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_delivered ON webhook_deliveries(webhook_id, delivered_at)
+    WHERE status = 'DELIVERED';
+
+COMMENT ON COLUMN webhook_configs.priority_class IS 'PRIORITY (paid plans, set by the platform admins), STANDARD or BULK';
+COMMENT ON COLUMN webhook_deliveries.priority_class IS 'Priority class of the webhook when the delivery was created';
@@ -0,0 +1,2 @@
+-- Drop festival CORS origins
+DROP TABLE IF EXISTS festival_cors_origins;
@@ -0,0 +1,15 @@
+-- Origins of the festival web apps hosted on their own domains, allowed to call the
+-- festival routes of the API from a browser on top of the platform origins
+CREATE TABLE IF NOT EXISTS festival_cors_origins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    festival_id UUID NOT NULL REFERENCES festivals(id) ON DELETE CASCADE,
+    origin VARCHAR(255) NOT NULL,
+    description VARCHAR(255),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_festival_cors_origins_festival_origin ON festival_cors_origins(festival_id, origin);
+
+COMMENT ON TABLE festival_cors_origins IS 'Browser origins allowed on the routes of a festival, cached by every API instance';
+COMMENT ON COLUMN festival_cors_origins.origin IS 'scheme://host[:port], lowercase, without path or wildcard';
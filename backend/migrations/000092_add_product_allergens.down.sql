@@ -0,0 +1,9 @@
+-- Drop product allergen declarations
+DROP INDEX IF EXISTS idx_products_undeclared;
+DROP INDEX IF EXISTS idx_products_dietary_tags;
+DROP INDEX IF EXISTS idx_products_allergens;
+
+ALTER TABLE products DROP COLUMN IF EXISTS nutrition;
+ALTER TABLE products DROP COLUMN IF EXISTS dietary_tags;
+ALTER TABLE products DROP COLUMN IF EXISTS allergens_declared_at;
+ALTER TABLE products DROP COLUMN IF EXISTS allergens;
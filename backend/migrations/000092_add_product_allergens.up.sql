@@ -0,0 +1,18 @@
+-- Allergen, dietary and nutrition declarations of the products, as required for food
+-- and drinks by Regulation (EU) No 1169/2011
+ALTER TABLE products ADD COLUMN IF NOT EXISTS allergens JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS allergens_declared_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS dietary_tags JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS nutrition JSONB;
+
+-- Menu filters on allergens and dietary tags
+CREATE INDEX IF NOT EXISTS idx_products_allergens ON products USING GIN (allergens);
+CREATE INDEX IF NOT EXISTS idx_products_dietary_tags ON products USING GIN (dietary_tags);
+
+-- Compliance report of the food and drinks missing their declaration
+CREATE INDEX IF NOT EXISTS idx_products_undeclared ON products(stand_id, category) WHERE allergens_declared_at IS NULL;
+
+COMMENT ON COLUMN products.allergens IS 'EU 14 allergens the product contains, in Annex II order; empty with allergens_declared_at set declares none';
+COMMENT ON COLUMN products.allergens_declared_at IS 'Last declaration of the allergens; NULL when they are unknown';
+COMMENT ON COLUMN products.dietary_tags IS 'VEGAN, VEGETARIAN, HALAL or GLUTEN_FREE, consistent with the allergens';
+COMMENT ON COLUMN products.nutrition IS 'Nutrition declaration per 100g or 100ml';
@@ -0,0 +1,8 @@
+COMMENT ON COLUMN wallets.last_activity_digest_at IS NULL;
+COMMENT ON COLUMN wallets.activity_push IS NULL;
+
+DROP INDEX IF EXISTS idx_wallets_activity_digest;
+
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS chk_wallets_activity_push;
+ALTER TABLE wallets DROP COLUMN IF EXISTS last_activity_digest_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS activity_push;
@@ -0,0 +1,12 @@
+-- How wallet holders are told about purchases and top-ups: a push per transaction, a
+-- nightly digest of the day's spending, or nothing
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS activity_push VARCHAR(20) NOT NULL DEFAULT 'INSTANT';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS last_activity_digest_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE wallets ADD CONSTRAINT chk_wallets_activity_push CHECK (activity_push IN ('INSTANT', 'DIGEST', 'OFF'));
+
+-- Wallets of a festival due for their nightly digest
+CREATE INDEX IF NOT EXISTS idx_wallets_activity_digest ON wallets(festival_id, last_activity_digest_at) WHERE activity_push = 'DIGEST';
+
+COMMENT ON COLUMN wallets.activity_push IS 'INSTANT for a push per purchase and top-up, DIGEST for a nightly summary, OFF for none';
+COMMENT ON COLUMN wallets.last_activity_digest_at IS 'End of the period of the last digest sent, or when digest mode was chosen';
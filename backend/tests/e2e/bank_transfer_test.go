@@ -0,0 +1,66 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/banktransfer"
+)
+
+// TestBankTransfer_StatementImportedTwice imports the same bank statement twice, which
+// only the unique bank reference of the transfers in Postgres keeps from crediting the
+// wallet twice
+func TestBankTransfer_StatementImportedTwice(t *testing.T) {
+	h := Setup(t)
+	ctx := context.Background()
+	setup := h.Seed(t)
+	startBalance := setup.Wallet.Balance
+
+	config := banktransfer.DefaultConfig()
+	config.AccountHolder = "Festival SA"
+	config.IBAN = "BE68539007547034"
+	service := banktransfer.NewService(banktransfer.NewRepository(h.DB), h.Wallets, config)
+
+	created, err := service.CreateOrder(ctx, setup.Festival.ID, banktransfer.CreateOrderRequest{
+		PayerName: "ACME Corp",
+		Lines:     []banktransfer.LineRequest{{WalletID: setup.Wallet.ID, Amount: 5000}},
+	}, nil)
+	require.NoError(t, err)
+	order := created.Order
+
+	booked := time.Now().UTC().Format("060102")
+	statement := fmt.Sprintf(`:20:STATEMENT
+:25:BE68539007547034
+:60F:C%[1]sEUR0,00
+:61:%[1]s%[2]sC50,00NTRFNONREF//REF1
+:86:Group booking %[3]s
+:62F:C%[1]sEUR50,00
+-`, booked, booked[2:], order.Reference)
+
+	result, err := service.ImportStatement(ctx, setup.Festival.ID, []byte(statement))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Matched)
+
+	result, err = service.ImportStatement(ctx, setup.Festival.ID, []byte(statement))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Duplicate)
+	assert.Zero(t, result.Matched)
+
+	var transfers int64
+	require.NoError(t, h.DB.Model(&banktransfer.Transfer{}).Where("festival_id = ?", setup.Festival.ID).Count(&transfers).Error)
+	assert.Equal(t, int64(1), transfers)
+
+	paid, err := service.GetOrder(ctx, setup.Festival.ID, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, banktransfer.OrderPaid, paid.Order.Status)
+	assert.True(t, paid.Order.Credited())
+
+	w, err := h.Wallets.GetWallet(ctx, setup.Wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, startBalance+5000, w.Balance, "credited once")
+}
@@ -0,0 +1,163 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Images of the dependencies, matching docker-compose.yml
+const (
+	PostgresImage   = "postgis/postgis:16-3.4-alpine"
+	RedisImage      = "redis:7-alpine"
+	MinioImage      = "minio/minio:latest"
+	StripeMockImage = "stripe/stripe-mock:latest"
+)
+
+// Credentials of the dependencies started for the tests
+const (
+	postgresDatabase = "festivals_e2e"
+	postgresUser     = "e2e"
+	postgresPassword = "e2e"
+
+	minioAccessKey = "e2e-access-key"
+	minioSecretKey = "e2e-secret-key"
+)
+
+// Dependencies holds the containers the API and the worker run against
+type Dependencies struct {
+	PostgresDSN   string
+	RedisAddr     string
+	MinioEndpoint string
+	StripeURL     string
+
+	containers []testcontainers.Container
+}
+
+// StartDependencies starts Postgres, Redis, MinIO and the Stripe mock. The containers
+// started so far are terminated when one of them fails to start.
+func StartDependencies(ctx context.Context) (*Dependencies, error) {
+	deps := &Dependencies{}
+	starters := []func(context.Context) error{
+		deps.startPostgres,
+		deps.startRedis,
+		deps.startMinio,
+		deps.startStripeMock,
+	}
+
+	for _, start := range starters {
+		if err := start(ctx); err != nil {
+			deps.Terminate(ctx)
+			return nil, err
+		}
+	}
+
+	return deps, nil
+}
+
+// Terminate stops and removes the containers
+func (d *Dependencies) Terminate(ctx context.Context) {
+	for _, container := range d.containers {
+		_ = container.Terminate(ctx)
+	}
+	d.containers = nil
+}
+
+func (d *Dependencies) startPostgres(ctx context.Context) error {
+	container, err := tcpostgres.RunContainer(ctx,
+		testcontainers.WithImage(PostgresImage),
+		tcpostgres.WithDatabase(postgresDatabase),
+		tcpostgres.WithUsername(postgresUser),
+		tcpostgres.WithPassword(postgresPassword),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(90*time.Second),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start PostgreSQL container: %w", err)
+	}
+	d.containers = append(d.containers, container)
+
+	d.PostgresDSN, err = container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		return fmt.Errorf("failed to get PostgreSQL connection string: %w", err)
+	}
+	return nil
+}
+
+func (d *Dependencies) startRedis(ctx context.Context) error {
+	container, err := tcredis.RunContainer(ctx,
+		testcontainers.WithImage(RedisImage),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("Ready to accept connections").
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start Redis container: %w", err)
+	}
+	d.containers = append(d.containers, container)
+
+	d.RedisAddr, err = container.Endpoint(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to get Redis address: %w", err)
+	}
+	return nil
+}
+
+func (d *Dependencies) startMinio(ctx context.Context) error {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        MinioImage,
+			Cmd:          []string{"server", "/data"},
+			ExposedPorts: []string{"9000/tcp"},
+			Env: map[string]string{
+				"MINIO_ROOT_USER":     minioAccessKey,
+				"MINIO_ROOT_PASSWORD": minioSecretKey,
+			},
+			WaitingFor: wait.ForHTTP("/minio/health/live").
+				WithPort("9000/tcp").
+				WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start MinIO container: %w", err)
+	}
+	d.containers = append(d.containers, container)
+
+	d.MinioEndpoint, err = container.PortEndpoint(ctx, "9000/tcp", "")
+	if err != nil {
+		return fmt.Errorf("failed to get MinIO endpoint: %w", err)
+	}
+	return nil
+}
+
+func (d *Dependencies) startStripeMock(ctx context.Context) error {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        StripeMockImage,
+			ExposedPorts: []string{"12111/tcp"},
+			WaitingFor: wait.ForListeningPort("12111/tcp").
+				WithStartupTimeout(30 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start Stripe mock container: %w", err)
+	}
+	d.containers = append(d.containers, container)
+
+	d.StripeURL, err = container.PortEndpoint(ctx, "12111/tcp", "http")
+	if err != nil {
+		return fmt.Errorf("failed to get Stripe mock endpoint: %w", err)
+	}
+	return nil
+}
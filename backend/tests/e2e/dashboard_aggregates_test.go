@@ -0,0 +1,158 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/stats"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/tests/helpers"
+)
+
+// minuteAggregate is a row of dashboard_minute_aggregates without its update time
+type minuteAggregate struct {
+	StandID       uuid.UUID
+	Bucket        time.Time
+	TopUps        int64
+	Purchases     int64
+	Refunds       int64
+	Volume        int64
+	Transactions  int
+	PurchaseCount int
+	Orders        int
+	OrderRevenue  int64
+}
+
+// productAggregate is a row of dashboard_product_aggregates without its update time
+type productAggregate struct {
+	StandID   uuid.UUID
+	ProductID uuid.UUID
+	Bucket    time.Time
+	Quantity  int
+	Revenue   int64
+}
+
+// TestDashboardAggregates_IncrementalMatchesRebuild refreshes the dashboard aggregates
+// as sales come in, some committed late or out of order, and checks they end up equal
+// to the aggregates rebuilt from scratch
+func TestDashboardAggregates_IncrementalMatchesRebuild(t *testing.T) {
+	h := Setup(t)
+	ctx := context.Background()
+	setup := h.Seed(t)
+	festivalID := setup.Festival.ID
+	repo := stats.NewRepository(h.DB)
+	lag := stats.DefaultDashboardAggregateConfig().Lag // 2 minutes
+
+	grill := setup.Stand.ID
+	bar := helpers.CreateTestStand(t, h.DB, festivalID, &helpers.StandOptions{Name: helpers.StringPtr("Main Bar")}).ID
+
+	// Minutes after base; sales happen half way through their minute
+	base := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	minute := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+
+	backdate := func(table string, id uuid.UUID, m int) {
+		require.NoError(t, h.DB.Exec("UPDATE "+table+" SET created_at = ? WHERE id = ?", minute(m).Add(30*time.Second), id).Error)
+	}
+	transaction := func(txType wallet.TransactionType, standID *uuid.UUID, amount int64, m int) {
+		tx := helpers.CreateTestTransaction(t, h.DB, setup.Wallet.ID, 0, 0, &helpers.TransactionOptions{
+			Type:    &txType,
+			Amount:  &amount,
+			StandID: standID,
+		})
+		backdate("transactions", tx.ID, m)
+	}
+	sale := func(standID uuid.UUID, amount int64, m int) {
+		transaction(wallet.TransactionTypePurchase, &standID, -amount, m)
+		paid := order.OrderStatusPaid
+		o := helpers.CreateTestOrder(t, h.DB, festivalID, setup.User.ID, setup.Wallet.ID, standID, &helpers.OrderOptions{
+			Items: &order.OrderItems{{
+				ProductID: setup.Product.ID, ProductName: setup.Product.Name,
+				Quantity: 1, UnitPrice: amount, TotalPrice: amount,
+			}},
+			TotalAmount: &amount,
+			Status:      &paid,
+		})
+		backdate("orders", o.ID, m)
+	}
+	refresh := func(m int, rebuild bool) {
+		require.NoError(t, repo.MaterializeDashboardAggregates(ctx, festivalID, minute(m), lag, rebuild))
+	}
+	snapshot := func() ([]minuteAggregate, []productAggregate) {
+		var minutes []minuteAggregate
+		require.NoError(t, h.DB.Raw(`SELECT stand_id, bucket, top_ups, purchases, refunds, volume, transactions,
+				purchase_count, orders, order_revenue
+			FROM dashboard_minute_aggregates WHERE festival_id = ? ORDER BY bucket, stand_id`, festivalID).Scan(&minutes).Error)
+		var products []productAggregate
+		require.NoError(t, h.DB.Raw(`SELECT stand_id, product_id, bucket, quantity, revenue
+			FROM dashboard_product_aggregates WHERE festival_id = ? ORDER BY bucket, stand_id, product_id`, festivalID).Scan(&products).Error)
+		return minutes, products
+	}
+	purchases := func(minutes []minuteAggregate) (total int64, orders int) {
+		for _, a := range minutes {
+			total += a.Purchases
+			orders += a.Orders
+		}
+		return total, orders
+	}
+
+	transaction(wallet.TransactionTypeTopUp, nil, 5000, 0)
+	sale(grill, 450, 1)
+	sale(bar, 300, 5)
+	refresh(10, false)
+
+	// Committed after the refresh, but within the lag of its watermark
+	sale(grill, 700, 9)
+	// Out of order: the later sale is recorded first
+	sale(bar, 250, 12)
+	sale(grill, 120, 11)
+	transaction(wallet.TransactionTypeRefund, &bar, 300, 13)
+	// Not due before the next refresh
+	sale(bar, 60, 17)
+	refresh(15, false)
+
+	minutes, _ := snapshot()
+	total, orders := purchases(minutes)
+	assert.Equal(t, int64(450+300+700+250+120), total)
+	assert.Equal(t, 5, orders)
+
+	// Late again, in a bucket the previous refresh already wrote
+	sale(grill, 800, 14)
+	sale(bar, 500, 16)
+	refresh(20, false)
+
+	incrementalMinutes, incrementalProducts := snapshot()
+	total, orders = purchases(incrementalMinutes)
+	assert.Equal(t, int64(450+300+700+250+120+800+500+60), total)
+	assert.Equal(t, 8, orders)
+
+	refresh(20, true)
+	rebuiltMinutes, rebuiltProducts := snapshot()
+	assert.Equal(t, rebuiltMinutes, incrementalMinutes)
+	assert.Equal(t, rebuiltProducts, incrementalProducts)
+
+	// Refreshing again changes nothing
+	refresh(20, false)
+	againMinutes, againProducts := snapshot()
+	assert.Equal(t, rebuiltMinutes, againMinutes)
+	assert.Equal(t, rebuiltProducts, againProducts)
+
+	t.Run("sale older than the lag needs a rebuild", func(t *testing.T) {
+		sale(grill, 999, 3)
+
+		refresh(25, false)
+		minutes, _ := snapshot()
+		total, _ := purchases(minutes)
+		assert.Equal(t, int64(3180), total)
+
+		refresh(25, true)
+		minutes, _ = snapshot()
+		total, _ = purchases(minutes)
+		assert.Equal(t, int64(3180+999), total)
+	})
+}
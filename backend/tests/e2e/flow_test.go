@@ -0,0 +1,120 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentintent"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/tests/helpers"
+)
+
+// TestTopUpOrderRefundReport follows the money of an attendee: a card top-up paid
+// through Stripe, an order paid with the wallet, its refund, and the transactions
+// report generated by the worker into the object storage
+func TestTopUpOrderRefundReport(t *testing.T) {
+	h := Setup(t)
+	ctx := context.Background()
+	setup := h.Seed(t)
+	startBalance := setup.Wallet.Balance
+
+	staff := h.Client(t).SetStaff(setup.Staff.ID)
+	attendee := h.Client(t).
+		SetUser(setup.User.ID).
+		SetFestival(setup.Festival.ID).
+		SetWallet(setup.Wallet.ID)
+
+	// Top-up paid by card
+	intent, err := paymentintent.New(&stripe.PaymentIntentParams{
+		Amount:             stripe.Int64(2000),
+		Currency:           stripe.String(string(stripe.CurrencyEUR)),
+		PaymentMethodTypes: []*string{stripe.String("card")},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, intent.ID)
+
+	var topUp struct {
+		Data wallet.TransactionResponse `json:"data"`
+	}
+	resp := staff.POST(fmt.Sprintf("/api/v1/wallets/%s/topup", setup.Wallet.ID), wallet.TopUpRequest{
+		Amount:        2000,
+		PaymentMethod: "card",
+		Reference:     intent.ID,
+	}).AssertOK()
+	require.NoError(t, resp.Unmarshal(&topUp))
+	assert.Equal(t, startBalance+2000, topUp.Data.BalanceAfter)
+
+	// Order paid with the wallet
+	var created struct {
+		Data order.OrderResponse `json:"data"`
+	}
+	resp = attendee.POST("/api/v1/orders", order.CreateOrderRequest{
+		StandID:       setup.Stand.ID,
+		Items:         []order.OrderItemRequest{{ProductID: setup.Product.ID, Quantity: 2}},
+		PaymentMethod: "wallet",
+	}).AssertCreated()
+	require.NoError(t, resp.Unmarshal(&created))
+	assert.Equal(t, 2*setup.Product.Price, created.Data.TotalAmount)
+
+	var paid struct {
+		Data order.OrderResponse `json:"data"`
+	}
+	resp = staff.POST(fmt.Sprintf("/api/v1/orders/%s/pay", created.Data.ID), nil).AssertOK()
+	require.NoError(t, resp.Unmarshal(&paid))
+	assert.Equal(t, order.OrderStatusPaid, paid.Data.Status)
+	assertBalance(t, staff, setup.Wallet.ID, startBalance+2000-created.Data.TotalAmount)
+
+	// Refund
+	var refunded struct {
+		Data order.OrderResponse `json:"data"`
+	}
+	resp = staff.POST(fmt.Sprintf("/api/v1/orders/%s/refund", created.Data.ID), order.RefundOrderRequest{
+		Reason: "Wrong drink",
+	}).AssertOK()
+	require.NoError(t, resp.Unmarshal(&refunded))
+	assert.Equal(t, order.OrderStatusRefunded, refunded.Data.Status)
+	assertBalance(t, staff, setup.Wallet.ID, startBalance+2000)
+
+	// Transactions report, generated by the worker
+	report, err := h.Reports.RequestReport(ctx, setup.Festival.ID, setup.Admin.ID, reports.ReportRequest{
+		Type:   reports.ReportTypeTransactions,
+		Format: reports.ReportFormatCSV,
+		Locale: "en",
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		report, err = h.Reports.GetReport(ctx, report.ID)
+		require.NoError(t, err)
+		return report.Status == reports.ReportStatusCompleted || report.Status == reports.ReportStatusFailed
+	}, 30*time.Second, 200*time.Millisecond)
+	require.Equal(t, reports.ReportStatusCompleted, report.Status, report.Error)
+
+	// Top-up, purchase and refund
+	assert.Equal(t, 3, report.RowCount)
+	content, err := h.ReadObject(ctx, report.FilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), intent.ID)
+}
+
+// assertBalance checks the balance of a wallet through the API
+func assertBalance(t *testing.T, client *helpers.TestClient, walletID uuid.UUID, expected int64) {
+	t.Helper()
+
+	var body struct {
+		Data wallet.WalletResponse `json:"data"`
+	}
+	resp := client.GET(fmt.Sprintf("/api/v1/wallets/%s", walletID)).AssertStatus(http.StatusOK)
+	require.NoError(t, resp.Unmarshal(&body))
+	assert.Equal(t, expected, body.Data.Balance)
+}
@@ -0,0 +1,335 @@
+// Package e2e runs the API and the worker against dockerized Postgres, Redis, MinIO
+// and Stripe mock containers, so that full flows are tested the way they run in
+// production: through the HTTP handlers, the queue and the real schema.
+//
+// The containers are started once per test binary by the first call to Setup and
+// shared by the tests, which get an empty database. New domains get coverage by
+// mounting their routes and task handlers on the harness:
+//
+//	h := e2e.Setup(t)
+//	h.Mount("tickets", func(api *gin.RouterGroup) {
+//		ticket.NewHandler(ticket.NewService(ticket.NewRepository(h.DB))).RegisterRoutes(api)
+//	})
+//	setup := h.Seed(t)
+//	h.Client(t).SetUser(setup.Admin.ID).SetFestival(setup.Festival.ID).GET("/api/v1/ticket-types").AssertOK()
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/testcontainers/testcontainers-go"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/order"
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/internal/domain/reports"
+	"github.com/mimi6060/festivals/backend/internal/domain/wallet"
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/storage"
+	"github.com/mimi6060/festivals/backend/tests/helpers"
+)
+
+// Settings of the services under test
+const (
+	JWTSecret    = "e2e-jwt-secret-key-for-testing-only"
+	StripeKey    = "sk_test_e2e"
+	ReportBucket = "festivals-e2e"
+)
+
+// Harness holds the API and the worker running against the dependencies
+type Harness struct {
+	Deps *Dependencies
+
+	DB      *gorm.DB
+	Redis   *redis.Client
+	Storage *storage.MinioStorage
+	Queue   *asynq.Client
+
+	Router *gin.Engine
+	// API is the /api/v1 group; requests are authenticated from the X-Test-* headers
+	// set by helpers.TestClient
+	API *gin.RouterGroup
+
+	Wallets *wallet.Service
+	Orders  *order.Service
+	Reports *reports.Service
+
+	worker  *asynq.Server
+	tasks   *asynq.ServeMux
+	mu      sync.Mutex
+	mounted map[string]bool
+}
+
+var (
+	shared    *Harness
+	sharedErr error
+	setupOnce sync.Once
+)
+
+// Setup returns the shared harness with an empty database, starting the containers
+// on the first call. It skips the test in short mode and when Docker is unavailable.
+func Setup(t *testing.T) *Harness {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping end-to-end test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	setupOnce.Do(func() {
+		shared, sharedErr = start(context.Background())
+	})
+	if sharedErr != nil {
+		t.Fatalf("Failed to start end-to-end harness: %v", sharedErr)
+	}
+
+	shared.Reset(t)
+	return shared
+}
+
+// Teardown stops the worker and the containers; call it from TestMain after the
+// tests have run
+func Teardown() {
+	if shared == nil {
+		return
+	}
+	shared.worker.Shutdown()
+	shared.Queue.Close()
+	shared.Redis.Close()
+	if sqlDB, err := shared.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+	shared.Deps.Terminate(context.Background())
+	shared = nil
+}
+
+// start starts the dependencies, applies the migrations and wires the API and the worker
+func start(ctx context.Context) (*Harness, error) {
+	deps, err := StartDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := wire(ctx, deps)
+	if err != nil {
+		deps.Terminate(ctx)
+		return nil, err
+	}
+	return h, nil
+}
+
+func wire(ctx context.Context, deps *Dependencies) (*Harness, error) {
+	db, err := gorm.Open(postgres.Open(deps.PostgresDSN), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+	if err := Migrate(db, MigrationsDir()); err != nil {
+		return nil, err
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: deps.RedisAddr})
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	minioStorage, err := storage.NewMinioStorage(storage.MinioConfig{
+		Endpoint:        deps.MinioEndpoint,
+		AccessKeyID:     minioAccessKey,
+		SecretAccessKey: minioSecretKey,
+		DefaultBucket:   ReportBucket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := minioStorage.CreateBucket(ctx, ReportBucket); err != nil {
+		return nil, err
+	}
+
+	// The payment code uses the global Stripe client
+	stripe.Key = StripeKey
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		URL:               stripe.String(deps.StripeURL),
+		MaxNetworkRetries: stripe.Int64(0),
+	}))
+
+	redisOpt := asynq.RedisClientOpt{Addr: deps.RedisAddr}
+	h := &Harness{
+		Deps:    deps,
+		DB:      db,
+		Redis:   rdb,
+		Storage: minioStorage,
+		Queue:   asynq.NewClient(redisOpt),
+		tasks:   asynq.NewServeMux(),
+		mounted: make(map[string]bool),
+	}
+
+	gin.SetMode(gin.TestMode)
+	h.Router = gin.New()
+	h.Router.Use(gin.Recovery())
+	h.API = h.Router.Group("/api/v1")
+	h.API.Use(testAuth())
+
+	h.Wallets = wallet.NewService(wallet.NewRepository(db), JWTSecret)
+	h.Orders = order.NewService(order.NewRepository(db), product.NewRepository(db), h.Wallets)
+	h.Reports = reports.NewService(reports.NewRepository(db), &bucketStorage{store: minioStorage, bucket: ReportBucket}, h.Queue, "")
+
+	// Requests are not signed: the harness has no POS devices
+	h.Mount("wallets", func(api *gin.RouterGroup) { wallet.NewHandler(h.Wallets).RegisterRoutes(api) })
+	h.Mount("orders", order.NewHandler(h.Orders).RegisterRoutes)
+	h.HandleTask(reports.TaskTypeGenerateReport, h.handleGenerateReport)
+
+	h.worker = asynq.NewServer(redisOpt, asynq.Config{
+		Concurrency: 2,
+		// Failed tasks are retried quickly so the tests do not wait for the backoff
+		RetryDelayFunc: func(int, error, *asynq.Task) time.Duration { return time.Second },
+	})
+	if err := h.worker.Start(h.tasks); err != nil {
+		return nil, fmt.Errorf("failed to start worker: %w", err)
+	}
+
+	return h, nil
+}
+
+// Mount registers the routes of a domain on the API once, however many tests mount it
+func (h *Harness) Mount(name string, register func(api *gin.RouterGroup)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.mounted["routes:"+name] {
+		return
+	}
+	h.mounted["routes:"+name] = true
+	register(h.API)
+}
+
+// HandleTask registers the worker handler of a task type once, however many tests
+// register it
+func (h *Harness) HandleTask(taskType string, handler asynq.HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.mounted["task:"+taskType] {
+		return
+	}
+	h.mounted["task:"+taskType] = true
+	h.tasks.HandleFunc(taskType, handler)
+}
+
+// Reset empties the database and Redis
+func (h *Harness) Reset(t *testing.T) {
+	t.Helper()
+
+	var tables []string
+	err := h.DB.Raw(`SELECT quote_ident(schemaname) || '.' || quote_ident(tablename) FROM pg_tables
+		WHERE schemaname = 'public' AND tablename NOT IN ('spatial_ref_sys')`).Scan(&tables).Error
+	if err != nil {
+		t.Fatalf("Failed to list tables: %v", err)
+	}
+	if len(tables) > 0 {
+		if err := h.DB.Exec("TRUNCATE TABLE " + strings.Join(tables, ", ") + " CASCADE").Error; err != nil {
+			t.Fatalf("Failed to truncate tables: %v", err)
+		}
+	}
+
+	if err := h.Redis.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+}
+
+// Seed creates an admin, a staff member, an attendee with a funded wallet and an
+// active festival with a stand selling a product
+func (h *Harness) Seed(t *testing.T) *helpers.TestSetup {
+	t.Helper()
+	return helpers.CreateFullTestSetup(t, h.DB)
+}
+
+// Client returns an HTTP client of the API, closed when the test ends
+func (h *Harness) Client(t *testing.T) *helpers.TestClient {
+	t.Helper()
+	client := helpers.NewTestClient(t, h.Router)
+	t.Cleanup(client.Close)
+	return client
+}
+
+// handleGenerateReport generates the reports requested through the queue, as the
+// report worker does
+func (h *Harness) handleGenerateReport(ctx context.Context, task *asynq.Task) error {
+	var payload reports.ReportTaskPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return h.Reports.GenerateReport(ctx, payload.ReportID)
+}
+
+// testAuth authenticates requests from the X-Test-* headers set by helpers.TestClient
+func testAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User-ID"); userID != "" {
+			c.Set("user_id", userID)
+		}
+		if staffID := c.GetHeader("X-Test-Staff-ID"); staffID != "" {
+			c.Set("staff_id", staffID)
+		}
+		if roles := c.GetHeader("X-Test-Roles"); roles != "" {
+			c.Set("roles", []string{roles})
+		}
+		if festivalID := c.GetHeader("X-Test-Festival-ID"); festivalID != "" {
+			c.Set("festival_id", festivalID)
+		}
+		if walletID := c.GetHeader("X-Test-Wallet-ID"); walletID != "" {
+			c.Set("wallet_id", walletID)
+		}
+		c.Next()
+	}
+}
+
+// MigrationsDir returns the directory of the SQL migrations of the backend
+func MigrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+}
+
+// concurrentIndex matches the CONCURRENTLY of index statements, which cannot run in
+// the implicit transaction of a multi-statement query. The tables are empty, so the
+// indexes are built without it.
+var concurrentIndex = regexp.MustCompile(`(?i)\bINDEX\s+CONCURRENTLY\b`)
+
+// Migrate applies the up migrations of dir in order
+func Migrate(db *gorm.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations found in %s", dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", filepath.Base(file), err)
+		}
+		statements := concurrentIndex.ReplaceAllString(string(sql), "INDEX")
+		if err := db.Exec(statements).Error; err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package e2e
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	// Stop the containers started by the tests
+	Teardown()
+
+	os.Exit(code)
+}
@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/search"
+	"github.com/mimi6060/festivals/backend/internal/domain/stand"
+	"github.com/mimi6060/festivals/backend/tests/helpers"
+)
+
+// TestCatalogSearchRanking checks the order of the festival catalog search against the
+// full-text and trigram indexes of the migrations
+func TestCatalogSearchRanking(t *testing.T) {
+	h := Setup(t)
+	h.Mount("search", func(api *gin.RouterGroup) {
+		handler := search.NewHandler(search.NewService(search.NewRepository(h.DB), h.Redis))
+		handler.RegisterFestivalRoutes(api.Group("/festivals/:festivalId"))
+	})
+	setup := h.Seed(t)
+	client := h.Client(t).SetUser(setup.User.ID)
+
+	closed := stand.StandStatusClosed
+	grill := helpers.CreateTestStand(t, h.DB, setup.Festival.ID, &helpers.StandOptions{
+		Name:        helpers.StringPtr("Green Grill"),
+		Description: helpers.StringPtr("Street food"),
+	})
+	shack := helpers.CreateTestStand(t, h.DB, setup.Festival.ID, &helpers.StandOptions{
+		Name:        helpers.StringPtr("Burger Shack"),
+		Description: helpers.StringPtr("Smash burgers"),
+		Status:      &closed,
+	})
+	helpers.CreateTestStand(t, h.DB, setup.Festival.ID, &helpers.StandOptions{
+		Name:        helpers.StringPtr("Burgr Bar"),
+		Description: helpers.StringPtr("Late night snacks"),
+	})
+
+	product := func(standID uuid.UUID, name, description string) {
+		helpers.CreateTestProduct(t, h.DB, standID, &helpers.ProductOptions{
+			Name:        helpers.StringPtr(name),
+			Description: helpers.StringPtr(description),
+			Category:    helpers.StringPtr("FOOD"),
+		})
+	}
+	product(grill.ID, "Vegan Burger", "Plant-based patty")
+	product(grill.ID, "Beyond Burger", "Vegan patty with cheddar")
+	product(grill.ID, "Loaded Fries", "Fries with vegan mayo")
+	product(shack.ID, "Burger Deluxe", "Vegan patty, double cheese")
+
+	// The same product at another festival
+	other := helpers.CreateActiveFestival(t, h.DB, &setup.Admin.ID)
+	otherStand := helpers.CreateTestStand(t, h.DB, other.ID, nil)
+	product(otherStand.ID, "Vegan Burger", "Plant-based patty")
+
+	searchCatalog := func(t *testing.T, params url.Values) search.SearchResponse {
+		t.Helper()
+		params.Set("lang", "en")
+		var body struct {
+			Data search.SearchResponse `json:"data"`
+		}
+		resp := client.GET(fmt.Sprintf("/api/v1/festivals/%s/search/catalog?%s", setup.Festival.ID, params.Encode())).AssertOK()
+		require.NoError(t, resp.Unmarshal(&body))
+		return body.Data
+	}
+	names := func(results []search.SearchResult) []string {
+		list := make([]string, 0, len(results))
+		for _, r := range results {
+			list = append(list, r.Name)
+		}
+		return list
+	}
+	assertRanked := func(t *testing.T, results []search.SearchResult) {
+		t.Helper()
+		for i := 1; i < len(results); i++ {
+			assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score, "%s ranked above %s", results[i-1].Name, results[i].Name)
+		}
+	}
+
+	t.Run("name matches rank above description matches", func(t *testing.T) {
+		resp := searchCatalog(t, url.Values{"q": {"vegan burger"}, "type": {"product"}})
+
+		// Every word must match: the fries only mention "vegan"
+		require.Len(t, resp.Results, 3)
+		assert.EqualValues(t, 3, resp.Total)
+		assert.Equal(t, "Vegan Burger", resp.Results[0].Name)
+		assert.ElementsMatch(t, []string{"Vegan Burger", "Beyond Burger", "Burger Deluxe"}, names(resp.Results))
+		assertRanked(t, resp.Results)
+
+		for _, r := range resp.Results {
+			assert.Equal(t, setup.Festival.ID.String(), r.Metadata.FestivalID)
+			require.NotNil(t, r.Metadata.StandOpen)
+			assert.Equal(t, r.Metadata.StandID != shack.ID.String(), *r.Metadata.StandOpen, r.Name)
+		}
+	})
+
+	t.Run("pages follow the ranking", func(t *testing.T) {
+		all := searchCatalog(t, url.Values{"q": {"vegan burger"}, "type": {"product"}})
+		require.Len(t, all.Results, 3)
+
+		for page := 1; page <= 3; page++ {
+			resp := searchCatalog(t, url.Values{
+				"q": {"vegan burger"}, "type": {"product"}, "page": {fmt.Sprint(page)}, "per_page": {"1"},
+			})
+			require.Len(t, resp.Results, 1)
+			assert.Equal(t, all.Results[page-1].ID, resp.Results[0].ID, "page %d", page)
+			assert.Equal(t, 3, resp.TotalPages)
+		}
+	})
+
+	t.Run("exact matches rank above typo matches", func(t *testing.T) {
+		resp := searchCatalog(t, url.Values{"q": {"burger"}})
+
+		require.NotEmpty(t, resp.Results)
+		assertRanked(t, resp.Results)
+		// Only "Burgr Bar" is matched by name similarity alone
+		last := resp.Results[len(resp.Results)-1]
+		assert.Equal(t, "Burgr Bar", last.Name)
+		assert.Equal(t, search.SearchTypeStand, last.Type)
+		assert.Contains(t, names(resp.Results), "Burger Shack")
+	})
+
+	t.Run("misspelled query still finds the product", func(t *testing.T) {
+		resp := searchCatalog(t, url.Values{"q": {"vegan burgr"}, "type": {"product"}})
+
+		require.NotEmpty(t, resp.Results)
+		assert.Equal(t, "Vegan Burger", resp.Results[0].Name)
+		assert.Equal(t, grill.ID.String(), resp.Results[0].Metadata.StandID)
+	})
+
+	t.Run("open only leaves out closed stands", func(t *testing.T) {
+		resp := searchCatalog(t, url.Values{"q": {"vegan burger"}, "type": {"product"}, "open_only": {"true"}})
+
+		assert.Equal(t, []string{"Vegan Burger", "Beyond Burger"}, names(resp.Results))
+	})
+}
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mimi6060/festivals/backend/internal/domain/product"
+	"github.com/mimi6060/festivals/backend/tests/helpers"
+)
+
+// TestStockReservation_ConcurrentOrders reserves and converts limited stock from
+// concurrent orders, which only the row locks taken in Postgres keep from overselling
+func TestStockReservation_ConcurrentOrders(t *testing.T) {
+	h := Setup(t)
+	ctx := context.Background()
+	setup := h.Seed(t)
+	repo := product.NewStockReservationRepository(h.DB)
+
+	const stock = 3
+	const orders = 12
+	burger := helpers.CreateTestProduct(t, h.DB, setup.Stand.ID, &helpers.ProductOptions{
+		Name:  helpers.StringPtr("Limited Burger"),
+		Stock: helpers.IntPtr(stock),
+	})
+	fries := helpers.CreateTestProduct(t, h.DB, setup.Stand.ID, &helpers.ProductOptions{
+		Name:  helpers.StringPtr("Limited Fries"),
+		Stock: helpers.IntPtr(orders),
+	})
+
+	now := time.Now()
+	expiresAt := now.Add(15 * time.Minute)
+
+	// Every order holds both products, listed in either order
+	orderIDs := make([]uuid.UUID, orders)
+	errs := make([]error, orders)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range orderIDs {
+		orderIDs[i] = uuid.New()
+		holds := []product.StockHold{
+			{ProductID: burger.ID, Quantity: 1},
+			{ProductID: fries.ID, Quantity: 1},
+		}
+		if i%2 == 1 {
+			holds[0], holds[1] = holds[1], holds[0]
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = repo.Reserve(ctx, orderIDs[i], holds, now, expiresAt)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var reserved []uuid.UUID
+	for i, err := range errs {
+		if err == nil {
+			reserved = append(reserved, orderIDs[i])
+			continue
+		}
+		assert.ErrorIs(t, err, product.ErrInsufficientStock)
+	}
+	require.Len(t, reserved, stock, "only the stock of the burger can be held")
+
+	held := func(productID uuid.UUID) int {
+		var quantity int
+		require.NoError(t, h.DB.Raw(`SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations
+			WHERE product_id = ? AND status = ?`, productID, product.ReservationStatusHeld).Scan(&quantity).Error)
+		return quantity
+	}
+	assert.Equal(t, stock, held(burger.ID))
+	// Failed orders hold nothing, not even the products they could have had
+	assert.Equal(t, stock, held(fries.ID))
+
+	t.Run("concurrent payments take the stock once each", func(t *testing.T) {
+		updates := []product.StockUpdate{
+			{ProductID: burger.ID, Delta: -1},
+			{ProductID: fries.ID, Delta: -1},
+		}
+		errs := make([]error, len(reserved))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, orderID := range reserved {
+			wg.Add(1)
+			go func(i int, orderID uuid.UUID) {
+				defer wg.Done()
+				<-start
+				errs[i] = repo.Convert(ctx, orderID, updates)
+			}(i, orderID)
+		}
+		close(start)
+		wg.Wait()
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		var products []product.Product
+		require.NoError(t, h.DB.Where("id IN ?", []uuid.UUID{burger.ID, fries.ID}).Order("name").Find(&products).Error)
+		require.Len(t, products, 2)
+		assert.Equal(t, 0, *products[0].Stock)
+		assert.Equal(t, product.ProductStatusOutOfStock, products[0].Status)
+		assert.Equal(t, orders-stock, *products[1].Stock)
+		assert.Equal(t, 0, held(burger.ID))
+		assert.Equal(t, 0, held(fries.ID))
+
+		// Nothing left to hold
+		err := repo.Reserve(ctx, uuid.New(), []product.StockHold{{ProductID: burger.ID, Quantity: 1}}, now, expiresAt)
+		assert.ErrorIs(t, err, product.ErrInsufficientStock)
+	})
+}
@@ -0,0 +1,38 @@
+package e2e
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/mimi6060/festivals/backend/internal/infrastructure/storage"
+)
+
+// bucketStorage stores the files of a service in one bucket of the object storage
+type bucketStorage struct {
+	store  *storage.MinioStorage
+	bucket string
+}
+
+func (s *bucketStorage) Upload(ctx context.Context, key string, data io.Reader, contentType string) error {
+	_, err := s.store.Upload(ctx, s.bucket, key, data, -1, storage.UploadOptions{ContentType: contentType})
+	return err
+}
+
+func (s *bucketStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.store.GetSignedURL(ctx, s.bucket, key, expiry)
+}
+
+func (s *bucketStorage) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, s.bucket, key)
+}
+
+// ReadObject returns the content of an object of the report bucket
+func (h *Harness) ReadObject(ctx context.Context, key string) ([]byte, error) {
+	reader, err := h.Storage.Download(ctx, ReportBucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -32,8 +32,8 @@ func SetupTestDB(t *testing.T) *TestDB {
 	ctx := context.Background()
 
 	// Start PostgreSQL container
-	postgresC, err := tcpostgres.Run(ctx,
-		"postgres:15-alpine",
+	postgresC, err := tcpostgres.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
 		tcpostgres.WithDatabase("festivals_test"),
 		tcpostgres.WithUsername("test"),
 		tcpostgres.WithPassword("test"),
@@ -69,8 +69,8 @@ func SetupTestDB(t *testing.T) *TestDB {
 	}
 
 	// Start Redis container
-	redisC, err := tcredis.Run(ctx,
-		"redis:7-alpine",
+	redisC, err := tcredis.RunContainer(ctx,
+		testcontainers.WithImage("redis:7-alpine"),
 		testcontainers.WithWaitStrategy(
 			wait.ForLog("Ready to accept connections").
 				WithStartupTimeout(30*time.Second),
@@ -401,7 +401,7 @@ func CreateTestWallet(t *testing.T, db *gorm.DB, userID, festivalID uuid.UUID, o
 
 	w := &wallet.Wallet{
 		ID:         id,
-		UserID:     userID,
+		UserID:     &userID,
 		FestivalID: festivalID,
 		Balance:    balance,
 		Status:     status,
@@ -0,0 +1,158 @@
+package performance
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultThresholdsFile is the k6 thresholds file shared with the load tests, relative
+// to this package
+const defaultThresholdsFile = "../../../tests/load/config/thresholds.json"
+
+// LatencyBudget is the baseline latency allowed for a path, in milliseconds
+type LatencyBudget struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// CriticalPathBudgets are the budgets of scenarios.critical_paths in thresholds.json
+type CriticalPathBudgets struct {
+	OrderCreate LatencyBudget `json:"order_create_latency"`
+	OrderPay    LatencyBudget `json:"order_pay_latency"`
+	WalletTopUp LatencyBudget `json:"wallet_topup_latency"`
+	TicketScan  LatencyBudget `json:"ticket_scan_latency"`
+	Success     struct {
+		Rate float64 `json:"rate"`
+	} `json:"critical_path_success"`
+}
+
+// loadBudgets reads the critical path budgets, from PERF_THRESHOLDS_FILE if set
+func loadBudgets(path string) (CriticalPathBudgets, error) {
+	var file struct {
+		Scenarios struct {
+			CriticalPaths struct {
+				Thresholds CriticalPathBudgets `json:"thresholds"`
+			} `json:"critical_paths"`
+		} `json:"scenarios"`
+	}
+
+	if override := os.Getenv("PERF_THRESHOLDS_FILE"); override != "" {
+		path = override
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CriticalPathBudgets{}, fmt.Errorf("failed to read thresholds: %w", err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return CriticalPathBudgets{}, fmt.Errorf("failed to parse thresholds: %w", err)
+	}
+	return file.Scenarios.CriticalPaths.Thresholds, nil
+}
+
+// LatencySample collects the latencies and failures of one path
+type LatencySample struct {
+	Name      string
+	durations []time.Duration
+	failures  int
+}
+
+func (s *LatencySample) Record(d time.Duration, err error) {
+	if err != nil {
+		s.failures++
+		return
+	}
+	s.durations = append(s.durations, d)
+}
+
+// Percentile returns the nearest-rank percentile of the successful calls in milliseconds
+func (s *LatencySample) Percentile(p float64) float64 {
+	if len(s.durations) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(s.durations))
+	copy(sorted, s.durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return float64(sorted[rank]) / float64(time.Millisecond)
+}
+
+// SuccessRate returns the share of calls that succeeded
+func (s *LatencySample) SuccessRate() float64 {
+	total := len(s.durations) + s.failures
+	if total == 0 {
+		return 0
+	}
+	return float64(len(s.durations)) / float64(total)
+}
+
+// Violations lists the percentiles of the sample that exceed the budget
+func (s *LatencySample) Violations(budget LatencyBudget) []string {
+	var violations []string
+	for _, check := range []struct {
+		name   string
+		p      float64
+		budget float64
+	}{
+		{"p50", 50, budget.P50},
+		{"p95", 95, budget.P95},
+		{"p99", 99, budget.P99},
+	} {
+		if check.budget <= 0 {
+			continue
+		}
+		if got := s.Percentile(check.p); got > check.budget {
+			violations = append(violations, fmt.Sprintf("%s %s %.1fms exceeds budget %.0fms", s.Name, check.name, got, check.budget))
+		}
+	}
+	return violations
+}
+
+// TestLoadBudgets tests that the shared thresholds file defines every critical path budget
+func TestLoadBudgets(t *testing.T) {
+	budgets, err := loadBudgets(defaultThresholdsFile)
+	require.NoError(t, err)
+
+	for name, budget := range map[string]LatencyBudget{
+		"order_create_latency": budgets.OrderCreate,
+		"order_pay_latency":    budgets.OrderPay,
+		"wallet_topup_latency": budgets.WalletTopUp,
+		"ticket_scan_latency":  budgets.TicketScan,
+	} {
+		assert.Positive(t, budget.P50, name)
+		assert.LessOrEqual(t, budget.P50, budget.P95, name)
+		assert.LessOrEqual(t, budget.P95, budget.P99, name)
+	}
+	assert.Positive(t, budgets.Success.Rate)
+}
+
+// TestLatencySample_Violations tests percentile computation against a budget
+func TestLatencySample_Violations(t *testing.T) {
+	sample := &LatencySample{Name: "ticket_scan"}
+	for i := 1; i <= 100; i++ {
+		sample.Record(time.Duration(i)*time.Millisecond, nil)
+	}
+	sample.Record(0, fmt.Errorf("timeout"))
+
+	assert.Equal(t, 50.0, sample.Percentile(50))
+	assert.Equal(t, 95.0, sample.Percentile(95))
+	assert.InDelta(t, 100.0/101.0, sample.SuccessRate(), 0.0001)
+
+	assert.Empty(t, sample.Violations(LatencyBudget{P50: 50, P95: 100, P99: 200}))
+	assert.Equal(t,
+		[]string{"ticket_scan p95 95.0ms exceeds budget 90ms"},
+		sample.Violations(LatencyBudget{P50: 50, P95: 90, P99: 200}),
+	)
+}
@@ -0,0 +1,153 @@
+package performance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topUpAmount funds the seeded wallet; it covers several orders so the order path
+// does not run out of balance while the top-up path is measured
+const topUpAmount = 2000
+
+// TestCriticalPathLatencyBudgets runs each critical path against the stack and fails
+// when a percentile exceeds the baseline budget from thresholds.json
+func TestCriticalPathLatencyBudgets(t *testing.T) {
+	cfg, ok := loadStackConfig(t)
+	if !ok {
+		t.Skip("PERF_BASE_URL not set, skipping performance suite")
+	}
+
+	budgets, err := loadBudgets(defaultThresholdsFile)
+	require.NoError(t, err)
+
+	client := NewStackClient(cfg)
+
+	// Warm up connections and caches so the first calls do not skew the percentiles
+	require.NoError(t, client.TopUp(topUpAmount*int64(cfg.Iterations)))
+	orderID, err := client.CreateOrder()
+	require.NoError(t, err)
+	require.NoError(t, client.PayOrder(orderID))
+	require.NoError(t, client.ScanTicket(cfg.TicketCodes[0]))
+
+	orderCreate := &LatencySample{Name: "order_create"}
+	orderPay := &LatencySample{Name: "order_pay"}
+	walletTopUp := &LatencySample{Name: "wallet_topup"}
+	ticketScan := &LatencySample{Name: "ticket_scan"}
+
+	for i := 0; i < cfg.Iterations; i++ {
+		start := time.Now()
+		orderID, err := client.CreateOrder()
+		orderCreate.Record(time.Since(start), err)
+		if err == nil {
+			start = time.Now()
+			err = client.PayOrder(orderID)
+			orderPay.Record(time.Since(start), err)
+		}
+
+		start = time.Now()
+		err = client.TopUp(topUpAmount)
+		walletTopUp.Record(time.Since(start), err)
+
+		start = time.Now()
+		err = client.ScanTicket(cfg.TicketCodes[i%len(cfg.TicketCodes)])
+		ticketScan.Record(time.Since(start), err)
+	}
+
+	for _, path := range []struct {
+		sample *LatencySample
+		budget LatencyBudget
+	}{
+		{orderCreate, budgets.OrderCreate},
+		{orderPay, budgets.OrderPay},
+		{walletTopUp, budgets.WalletTopUp},
+		{ticketScan, budgets.TicketScan},
+	} {
+		s := path.sample
+		t.Logf("%-13s p50=%.1fms p95=%.1fms p99=%.1fms success=%.2f%%",
+			s.Name, s.Percentile(50), s.Percentile(95), s.Percentile(99), s.SuccessRate()*100)
+
+		assert.GreaterOrEqual(t, s.SuccessRate(), budgets.Success.Rate, "%s success rate", s.Name)
+		for _, violation := range s.Violations(path.budget) {
+			t.Error(violation)
+		}
+	}
+}
+
+// BenchmarkOrderCreateAndPay benchmarks a stand order followed by its wallet payment
+func BenchmarkOrderCreateAndPay(b *testing.B) {
+	cfg, ok := loadStackConfig(b)
+	if !ok {
+		b.Skip("PERF_BASE_URL not set, skipping performance suite")
+	}
+	client := NewStackClient(cfg)
+	if err := client.TopUp(topUpAmount * int64(b.N)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		orderID, err := client.CreateOrder()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := client.PayOrder(orderID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWalletTopUp benchmarks a cash wallet top-up
+func BenchmarkWalletTopUp(b *testing.B) {
+	cfg, ok := loadStackConfig(b)
+	if !ok {
+		b.Skip("PERF_BASE_URL not set, skipping performance suite")
+	}
+	client := NewStackClient(cfg)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.TopUp(topUpAmount); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkTicketScan benchmarks an entry gate ticket scan
+func BenchmarkTicketScan(b *testing.B) {
+	cfg, ok := loadStackConfig(b)
+	if !ok {
+		b.Skip("PERF_BASE_URL not set, skipping performance suite")
+	}
+	client := NewStackClient(cfg)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.ScanTicket(cfg.TicketCodes[i%len(cfg.TicketCodes)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkTicketScanParallel benchmarks concurrent scans from several entry gates
+func BenchmarkTicketScanParallel(b *testing.B) {
+	cfg, ok := loadStackConfig(b)
+	if !ok {
+		b.Skip("PERF_BASE_URL not set, skipping performance suite")
+	}
+	client := NewStackClient(cfg)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if err := client.ScanTicket(cfg.TicketCodes[i%len(cfg.TicketCodes)]); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package performance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// StackConfig describes the running docker-compose stack and the seeded data the
+// critical paths are exercised against
+type StackConfig struct {
+	BaseURL     string
+	Token       string
+	FestivalID  string
+	StandID     string
+	ProductID   string
+	WalletID    string
+	TicketCodes []string
+	Iterations  int
+}
+
+// loadStackConfig reads the stack configuration from the environment. It returns
+// false when PERF_BASE_URL is not set so the suite is skipped outside of
+// `make perf-test`.
+func loadStackConfig(tb testing.TB) (StackConfig, bool) {
+	cfg := StackConfig{
+		BaseURL:    strings.TrimRight(os.Getenv("PERF_BASE_URL"), "/"),
+		Token:      os.Getenv("PERF_TOKEN"),
+		FestivalID: os.Getenv("PERF_FESTIVAL_ID"),
+		StandID:    os.Getenv("PERF_STAND_ID"),
+		ProductID:  os.Getenv("PERF_PRODUCT_ID"),
+		WalletID:   os.Getenv("PERF_WALLET_ID"),
+		Iterations: 200,
+	}
+	if cfg.BaseURL == "" {
+		return cfg, false
+	}
+
+	for _, code := range strings.Split(os.Getenv("PERF_TICKET_CODES"), ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			cfg.TicketCodes = append(cfg.TicketCodes, code)
+		}
+	}
+	if raw := os.Getenv("PERF_ITERATIONS"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			tb.Fatalf("PERF_ITERATIONS must be a positive integer, got %q", raw)
+		}
+		cfg.Iterations = n
+	}
+
+	missing := []string{}
+	for name, value := range map[string]string{
+		"PERF_TOKEN":       cfg.Token,
+		"PERF_FESTIVAL_ID": cfg.FestivalID,
+		"PERF_STAND_ID":    cfg.StandID,
+		"PERF_PRODUCT_ID":  cfg.ProductID,
+		"PERF_WALLET_ID":   cfg.WalletID,
+	} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(cfg.TicketCodes) == 0 {
+		missing = append(missing, "PERF_TICKET_CODES")
+	}
+	if len(missing) > 0 {
+		tb.Fatalf("performance stack configuration incomplete, missing %s", strings.Join(missing, ", "))
+	}
+
+	return cfg, true
+}
+
+// StackClient calls the critical path endpoints of the stack
+type StackClient struct {
+	cfg    StackConfig
+	client *http.Client
+}
+
+func NewStackClient(cfg StackConfig) *StackClient {
+	return &StackClient{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CreateOrder creates a one item wallet order at the seeded stand and returns its ID
+func (c *StackClient) CreateOrder() (string, error) {
+	url := fmt.Sprintf("%s/api/v1/festivals/%s/orders?wallet_id=%s", c.cfg.BaseURL, c.cfg.FestivalID, c.cfg.WalletID)
+	body := map[string]interface{}{
+		"standId":       c.cfg.StandID,
+		"items":         []map[string]interface{}{{"productId": c.cfg.ProductID, "quantity": 1}},
+		"paymentMethod": "wallet",
+	}
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := c.post(url, body, http.StatusCreated, &created); err != nil {
+		return "", err
+	}
+	return created.Data.ID, nil
+}
+
+// PayOrder pays an order from the seeded wallet
+func (c *StackClient) PayOrder(orderID string) error {
+	url := fmt.Sprintf("%s/api/v1/festivals/%s/orders/%s/pay", c.cfg.BaseURL, c.cfg.FestivalID, orderID)
+	return c.post(url, nil, http.StatusOK, nil)
+}
+
+// TopUp tops the seeded wallet up with cash
+func (c *StackClient) TopUp(amount int64) error {
+	url := fmt.Sprintf("%s/api/v1/wallets/%s/topup", c.cfg.BaseURL, c.cfg.WalletID)
+	body := map[string]interface{}{
+		"amount":        amount,
+		"paymentMethod": "cash",
+	}
+	return c.post(url, body, http.StatusOK, nil)
+}
+
+// ScanTicket performs a CHECK scan, which verifies the ticket without recording an
+// entry, so the seeded tickets can be scanned repeatedly
+func (c *StackClient) ScanTicket(code string) error {
+	url := fmt.Sprintf("%s/api/v1/festivals/%s/tickets/scan", c.cfg.BaseURL, c.cfg.FestivalID)
+	body := map[string]interface{}{
+		"code":     code,
+		"scanType": "CHECK",
+		"location": "perf-gate",
+		"deviceId": "go-perf",
+	}
+
+	var scanned struct {
+		Data struct {
+			Success bool   `json:"success"`
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	if err := c.post(url, body, http.StatusOK, &scanned); err != nil {
+		return err
+	}
+	if !scanned.Data.Success {
+		return fmt.Errorf("scan of %s rejected: %s", code, scanned.Data.Message)
+	}
+	return nil
+}
+
+func (c *StackClient) post(url string, body interface{}, wantStatus int, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		var buf bytes.Buffer
+		buf.ReadFrom(resp.Body)
+		return fmt.Errorf("POST %s: expected status %d, got %d: %s", url, wantStatus, resp.StatusCode, buf.String())
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
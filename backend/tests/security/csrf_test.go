@@ -0,0 +1,116 @@
+package security_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mimi6060/festivals/backend/internal/middleware"
+)
+
+// ============================================================================
+// CSRF Protection Tests
+// ============================================================================
+
+func setupCSRFRouter(t *testing.T) (*gin.Engine, *middleware.SessionManager) {
+	redisClient := setupBruteForceTestRedis(t)
+	t.Cleanup(func() { redisClient.Close() })
+
+	sessionConfig := middleware.DefaultSessionConfig()
+	sessionConfig.RedisClient = redisClient
+	sessionConfig.SessionDuration = 1 * time.Hour
+	sessionConfig.KeyPrefix = "test:session:csrf:"
+	manager := middleware.NewSessionManager(sessionConfig)
+	csrfConfig := middleware.DefaultSessionCSRFConfig(manager)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.SessionCSRF(csrfConfig))
+	router.GET("/api/v1/csrf-token", middleware.CSRFTokenHandler(csrfConfig))
+	router.POST("/api/v1/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return router, manager
+}
+
+func csrfRequest(router *gin.Engine, method, path, sessionID, token, bearer string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if sessionID != "" {
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	}
+	if token != "" {
+		req.Header.Set("X-CSRF-Token", token)
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSessionCSRF(t *testing.T) {
+	router, manager := setupCSRFRouter(t)
+	session, err := manager.CreateSession(context.Background(), "user789", "192.168.1.3", "Mozilla/5.0")
+	require.NoError(t, err)
+
+	// Cookie without token
+	w := csrfRequest(router, http.MethodPost, "/api/v1/orders", session.ID, "", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "CSRF_MISSING")
+
+	// Bearer clients and requests without a session are not checked
+	w = csrfRequest(router, http.MethodPost, "/api/v1/orders", session.ID, "", "token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = csrfRequest(router, http.MethodPost, "/api/v1/orders", "", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = csrfRequest(router, http.MethodGet, "/api/v1/csrf-token", session.ID, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotEmpty(t, body.Data.Token)
+
+	w = csrfRequest(router, http.MethodPost, "/api/v1/orders", session.ID, body.Data.Token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = csrfRequest(router, http.MethodPost, "/api/v1/orders", session.ID, "forged", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "CSRF_INVALID")
+}
+
+func TestSessionCSRF_Rotation(t *testing.T) {
+	router, manager := setupCSRFRouter(t)
+	ctx := context.Background()
+	session, err := manager.CreateSession(ctx, "user790", "192.168.1.4", "Mozilla/5.0")
+	require.NoError(t, err)
+
+	token, err := manager.CSRFToken(ctx, session.ID)
+	require.NoError(t, err)
+	again, err := manager.CSRFToken(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	// The token of the old session is not accepted with the rotated one
+	rotated, err := manager.RotateSession(ctx, session.ID)
+	require.NoError(t, err)
+	w := csrfRequest(router, http.MethodPost, "/api/v1/orders", rotated.ID, token, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Unknown sessions authenticate nothing and have no token
+	token, err = manager.CSRFToken(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
@@ -0,0 +1,184 @@
+import http from 'k6/http';
+import { check, group, sleep } from 'k6';
+import { Rate, Trend } from 'k6/metrics';
+
+/**
+ * Critical Paths Scenario
+ *
+ * Exercises the three paths that must hold up on festival weekends and asserts
+ * the latency budgets from config/thresholds.json (scenarios.critical_paths):
+ * 1. Order create + wallet payment at a stand
+ * 2. Wallet top-up at a top-up booth
+ * 3. Ticket scan at an entry gate
+ *
+ * Run against the docker-compose stack with `make load-critical-paths`. The
+ * stack must be seeded with a festival, a stand with a product, a wallet and
+ * at least one ticket; their IDs are passed through the environment.
+ */
+
+const budgets = JSON.parse(open('../../config/thresholds.json')).scenarios.critical_paths.thresholds;
+
+// Custom metrics
+const orderCreateLatency = new Trend('order_create_latency', true);
+const orderPayLatency = new Trend('order_pay_latency', true);
+const walletTopUpLatency = new Trend('wallet_topup_latency', true);
+const ticketScanLatency = new Trend('ticket_scan_latency', true);
+const criticalPathSuccess = new Rate('critical_path_success');
+
+// Configuration
+const BASE_URL = __ENV.BASE_URL || 'http://localhost:8080';
+const TOKEN = __ENV.PERF_TOKEN;
+const FESTIVAL_ID = __ENV.PERF_FESTIVAL_ID;
+const STAND_ID = __ENV.PERF_STAND_ID;
+const PRODUCT_ID = __ENV.PERF_PRODUCT_ID;
+const WALLET_ID = __ENV.PERF_WALLET_ID;
+const TICKET_CODES = (__ENV.PERF_TICKET_CODES || '').split(',').filter((code) => code !== '');
+
+function latencyThresholds(budget) {
+  return [`p(50)<${budget.p50}`, `p(95)<${budget.p95}`, `p(99)<${budget.p99}`];
+}
+
+export const options = {
+  scenarios: {
+    stand_orders: {
+      executor: 'constant-arrival-rate',
+      exec: 'orderCreateAndPay',
+      rate: parseInt(__ENV.ORDER_RATE || '20'),
+      timeUnit: '1s',
+      duration: __ENV.DURATION || '2m',
+      preAllocatedVUs: 20,
+      maxVUs: 100,
+    },
+    topup_booths: {
+      executor: 'constant-arrival-rate',
+      exec: 'walletTopUp',
+      rate: parseInt(__ENV.TOPUP_RATE || '5'),
+      timeUnit: '1s',
+      duration: __ENV.DURATION || '2m',
+      preAllocatedVUs: 5,
+      maxVUs: 30,
+    },
+    entry_gates: {
+      executor: 'constant-arrival-rate',
+      exec: 'ticketScan',
+      rate: parseInt(__ENV.SCAN_RATE || '10'),
+      timeUnit: '1s',
+      duration: __ENV.DURATION || '2m',
+      preAllocatedVUs: 10,
+      maxVUs: 50,
+    },
+  },
+
+  thresholds: {
+    order_create_latency: latencyThresholds(budgets.order_create_latency),
+    order_pay_latency: latencyThresholds(budgets.order_pay_latency),
+    wallet_topup_latency: latencyThresholds(budgets.wallet_topup_latency),
+    ticket_scan_latency: latencyThresholds(budgets.ticket_scan_latency),
+    critical_path_success: [`rate>${budgets.critical_path_success.rate}`],
+  },
+
+  tags: {
+    test_type: 'scenario',
+    scenario: 'critical_paths',
+  },
+};
+
+function params(name) {
+  return {
+    headers: {
+      'Content-Type': 'application/json',
+      'Authorization': `Bearer ${TOKEN}`,
+    },
+    tags: { name: name },
+  };
+}
+
+export function setup() {
+  console.log('Starting critical paths scenario');
+
+  const missing = ['PERF_TOKEN', 'PERF_FESTIVAL_ID', 'PERF_STAND_ID', 'PERF_PRODUCT_ID', 'PERF_WALLET_ID']
+    .filter((name) => !__ENV[name]);
+  if (missing.length > 0 || TICKET_CODES.length === 0) {
+    throw new Error(`Missing environment: ${missing.concat(TICKET_CODES.length === 0 ? ['PERF_TICKET_CODES'] : []).join(', ')}`);
+  }
+
+  const healthCheck = http.get(`${BASE_URL}/health`);
+  if (healthCheck.status !== 200) {
+    throw new Error('API not available');
+  }
+
+  return { baseUrl: BASE_URL };
+}
+
+export function orderCreateAndPay(data) {
+  const festivalUrl = `${data.baseUrl}/api/v1/festivals/${FESTIVAL_ID}`;
+
+  group('Order Create + Pay', () => {
+    const createResponse = http.post(
+      `${festivalUrl}/orders?wallet_id=${WALLET_ID}`,
+      JSON.stringify({
+        standId: STAND_ID,
+        items: [{ productId: PRODUCT_ID, quantity: 1 }],
+        paymentMethod: 'wallet',
+      }),
+      params('order_create')
+    );
+    orderCreateLatency.add(createResponse.timings.duration);
+
+    const created = check(createResponse, {
+      'order created': (r) => r.status === 201,
+    });
+    criticalPathSuccess.add(created);
+    if (!created) {
+      return;
+    }
+
+    const orderId = createResponse.json('data.id');
+    const payResponse = http.post(`${festivalUrl}/orders/${orderId}/pay`, null, params('order_pay'));
+    orderPayLatency.add(payResponse.timings.duration);
+
+    criticalPathSuccess.add(check(payResponse, {
+      'order paid': (r) => r.status === 200,
+    }));
+  });
+}
+
+export function walletTopUp(data) {
+  group('Wallet Top-Up', () => {
+    const response = http.post(
+      `${data.baseUrl}/api/v1/wallets/${WALLET_ID}/topup`,
+      JSON.stringify({ amount: 2000, paymentMethod: 'cash' }),
+      params('wallet_topup')
+    );
+    walletTopUpLatency.add(response.timings.duration);
+
+    criticalPathSuccess.add(check(response, {
+      'wallet topped up': (r) => r.status === 200,
+    }));
+  });
+}
+
+export function ticketScan(data) {
+  const code = TICKET_CODES[Math.floor(Math.random() * TICKET_CODES.length)];
+
+  group('Ticket Scan', () => {
+    // CHECK scans verify the ticket without changing its entry state, so the
+    // same seeded tickets can be scanned for the whole run
+    const response = http.post(
+      `${data.baseUrl}/api/v1/festivals/${FESTIVAL_ID}/tickets/scan`,
+      JSON.stringify({ code: code, scanType: 'CHECK', location: 'perf-gate', deviceId: `k6-${__VU}` }),
+      params('ticket_scan')
+    );
+    ticketScanLatency.add(response.timings.duration);
+
+    criticalPathSuccess.add(check(response, {
+      'ticket scanned': (r) => r.status === 200 && r.json('data.success') === true,
+    }));
+  });
+
+  sleep(0.1);
+}
+
+export function teardown(data) {
+  console.log('Critical paths scenario completed');
+}